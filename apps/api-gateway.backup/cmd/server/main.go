@@ -182,6 +182,12 @@ func main() {
 				c.JSON(http.StatusOK, traefikService.GetTraefikConfig())
 			})
 		}
+
+		// Tyk API definition sync endpoints
+		if cfg.Tyk.Enabled {
+			serviceGroup.GET("/tyk/status", tykService.StatusEndpoint(tykServiceAPIs(cfg)))
+			serviceGroup.POST("/tyk/sync", tykService.SyncEndpoint(tykServiceAPIs(cfg)))
+		}
 	}
 
 	// API v1 routes with JWT authentication
@@ -225,9 +231,9 @@ func main() {
 		}
 	}
 
-	// Initialize Tyk API definitions if enabled
+	// Sync Tyk API definitions if enabled
 	if cfg.Tyk.Enabled {
-		go initializeTykAPIs(tykService, cfg)
+		go syncTykAPIs(tykService, cfg)
 	}
 
 	// Create HTTP server with configured timeouts
@@ -289,44 +295,54 @@ func main() {
 	}
 }
 
-// initializeTykAPIs initializes API definitions in Tyk
-func initializeTykAPIs(tykService *tyk.TykService, cfg *config.Config) {
-	ctx := context.Background()
-
-	// Define service configurations
-	services := map[string]string{
-		"auth-service":          "/api/v1/auth",
-		"form-service":          "/api/v1/forms",
-		"response-service":      "/api/v1/responses",
-		"analytics-service":     "/api/v1/analytics",
-		"collaboration-service": "/api/v1/collaboration",
-		"realtime-service":      "/api/v1/realtime",
+// syncTykAPIs reconciles the Tyk API definitions with the configured services
+func syncTykAPIs(tykService *tyk.TykService, cfg *config.Config) {
+	report, err := tykService.Sync(context.Background(), tykServiceAPIs(cfg))
+	if report == nil {
+		log.Printf("❌ Failed to sync Tyk API definitions: %v", err)
+		return
 	}
 
-	// Create API definitions for each service
-	for serviceName, listenPath := range services {
-		serviceConfig, exists := getServiceConfig(cfg, serviceName)
-		if !exists {
-			continue
+	for _, status := range append(report.Services, report.Pruned...) {
+		switch {
+		case status.State == tyk.SyncStateError:
+			log.Printf("❌ Failed to sync Tyk API definition %s: %s", status.APIID, status.Error)
+		case status.Action != "":
+			log.Printf("✅ Tyk API definition %s %s", status.APIID, status.Action)
 		}
-
-		apiDef := tykService.GetAPIDefinitionForService(serviceName, serviceConfig.URL, listenPath)
-
-		if err := tykService.CreateAPIDefinition(ctx, apiDef); err != nil {
-			log.Printf("❌ Failed to create Tyk API definition for %s: %v", serviceName, err)
-		} else {
-			log.Printf("✅ Created Tyk API definition for %s", serviceName)
+	}
+	for _, apiID := range report.Orphaned {
+		if !cfg.Tyk.Prune {
+			log.Printf("⚠️  Tyk API definition %s has no service, set TYK_PRUNE=true to delete it", apiID)
 		}
 	}
-
-	// Reload API definitions
-	if err := tykService.ReloadAPIDefinitions(ctx); err != nil {
+	if err != nil {
 		log.Printf("❌ Failed to reload Tyk API definitions: %v", err)
-	} else {
-		log.Printf("✅ Reloaded Tyk API definitions")
 	}
 }
 
+// tykServiceAPIs lists the services exposed through Tyk, in a stable order
+func tykServiceAPIs(cfg *config.Config) []tyk.ServiceAPI {
+	listenPaths := []struct{ service, path string }{
+		{"auth-service", "/api/v1/auth"},
+		{"form-service", "/api/v1/forms"},
+		{"response-service", "/api/v1/responses"},
+		{"analytics-service", "/api/v1/analytics"},
+		{"collaboration-service", "/api/v1/collaboration"},
+		{"realtime-service", "/api/v1/realtime"},
+	}
+
+	var services []tyk.ServiceAPI
+	for _, lp := range listenPaths {
+		serviceConfig, exists := getServiceConfig(cfg, lp.service)
+		if !exists {
+			continue
+		}
+		services = append(services, tyk.ServiceAPI{Name: lp.service, URL: serviceConfig.URL, ListenPath: lp.path})
+	}
+	return services
+}
+
 // getServiceConfig returns service configuration by name
 func getServiceConfig(cfg *config.Config, serviceName string) (config.ServiceConfig, bool) {
 	switch serviceName {
@@ -220,6 +220,7 @@ type TykConfig struct {
 	DashboardURL string       `json:"dashboard_url" yaml:"dashboard_url"`
 	APIKey       string       `json:"-" yaml:"-"` // Hidden from output
 	OrgID        string       `json:"org_id" yaml:"org_id"`
+	Prune        bool         `json:"prune" yaml:"prune"`
 	Policies     TykPolicies  `json:"policies" yaml:"policies"`
 	Analytics    TykAnalytics `json:"analytics" yaml:"analytics"`
 	Portal       TykPortal    `json:"portal" yaml:"portal"`
@@ -512,6 +513,7 @@ func Load() *Config {
 			DashboardURL: getEnv("TYK_DASHBOARD_URL", ""),
 			APIKey:       getEnv("TYK_API_KEY", ""),
 			OrgID:        getEnv("TYK_ORG_ID", ""),
+			Prune:        getBoolEnv("TYK_PRUNE", false),
 		},
 		Services: ServicesConfig{
 			AuthService: ServiceConfig{
@@ -0,0 +1,353 @@
+package tyk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// managedAPIPrefix starts the API ID of every definition the gateway manages.
+// Definitions without it are left alone, even when pruning.
+const managedAPIPrefix = "x-form-"
+
+// SyncState is the state of a service's API definition in the Tyk dashboard
+type SyncState string
+
+const (
+	// SyncStateInSync means the dashboard holds the definition the gateway expects
+	SyncStateInSync SyncState = "in_sync"
+	// SyncStateDrifted means the definition exists but was changed
+	SyncStateDrifted SyncState = "drifted"
+	// SyncStateMissing means the dashboard holds no definition for the service
+	SyncStateMissing SyncState = "missing"
+	// SyncStateError means the definition could not be read or written
+	SyncStateError SyncState = "error"
+)
+
+// ServiceAPI is a service exposed through Tyk
+type ServiceAPI struct {
+	Name       string
+	URL        string
+	ListenPath string
+}
+
+// APIStatus is the state of the API definition of one service
+type APIStatus struct {
+	Service string    `json:"service"`
+	APIID   string    `json:"api_id"`
+	State   SyncState `json:"state"`
+	// Action is what a sync did: created, updated or deleted
+	Action string `json:"action,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// SyncReport lists the state of every service's API definition
+type SyncReport struct {
+	Services []APIStatus `json:"services"`
+	// Orphaned lists the API IDs of managed definitions no service declares
+	// any more, deleted by a sync when pruning is enabled
+	Orphaned []string    `json:"orphaned,omitempty"`
+	Pruned   []APIStatus `json:"pruned,omitempty"`
+	// Error is set when Tyk failed to reload the changed definitions
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// InSync reports whether every definition matches its service
+func (r *SyncReport) InSync() bool {
+	for _, status := range r.Services {
+		if status.State != SyncStateInSync {
+			return false
+		}
+	}
+	for _, status := range r.Pruned {
+		if status.State == SyncStateError {
+			return false
+		}
+	}
+	return len(r.Orphaned) == len(r.Pruned)
+}
+
+// APIIDForService returns the API ID of a service's definition. It only
+// depends on the service name, so a sync finds the definitions it created
+// before instead of creating duplicates.
+func APIIDForService(serviceName string) string {
+	return fmt.Sprintf("%s%s-api", managedAPIPrefix, serviceName)
+}
+
+// dashboardAPI is an API definition as the dashboard API wraps it
+type dashboardAPI struct {
+	APIDefinition *TykAPIDefinition `json:"api_definition"`
+}
+
+// dashboardAPIList is a page of GET /api/apis
+type dashboardAPIList struct {
+	APIs  []dashboardAPI `json:"apis"`
+	Pages int            `json:"pages"`
+}
+
+// Status compares the definitions in the dashboard with the services without
+// changing anything
+func (ts *TykService) Status(ctx context.Context, services []ServiceAPI) (*SyncReport, error) {
+	existing, err := ts.ListAPIDefinitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SyncReport{CheckedAt: time.Now()}
+	for _, service := range services {
+		status, _ := ts.compare(service, existing)
+		report.Services = append(report.Services, status)
+	}
+	report.Orphaned = orphans(services, existing)
+	return report, nil
+}
+
+// Sync reconciles the dashboard with the services: missing definitions are
+// created, drifted ones updated in place and, when pruning is enabled,
+// definitions of removed services deleted. Definitions in sync are not
+// written, so running Sync again changes nothing. Tyk reloads its
+// definitions when anything changed.
+func (ts *TykService) Sync(ctx context.Context, services []ServiceAPI) (*SyncReport, error) {
+	if !ts.config.Tyk.Enabled {
+		return &SyncReport{CheckedAt: time.Now()}, nil
+	}
+
+	ts.syncMu.Lock()
+	defer ts.syncMu.Unlock()
+
+	existing, err := ts.ListAPIDefinitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SyncReport{CheckedAt: time.Now()}
+	changed := false
+	for _, service := range services {
+		status, desired := ts.compare(service, existing)
+		switch status.State {
+		case SyncStateMissing:
+			status.Action = "created"
+			err = ts.CreateAPIDefinition(ctx, desired)
+		case SyncStateDrifted:
+			status.Action = "updated"
+			err = ts.UpdateAPIDefinition(ctx, desired)
+		default:
+			err = nil
+		}
+
+		if status.Action != "" {
+			if err != nil {
+				status.State, status.Error = SyncStateError, err.Error()
+				log.Printf("Failed to sync Tyk API definition %s: %v", status.APIID, err)
+			} else {
+				status.State = SyncStateInSync
+				changed = true
+			}
+		}
+		report.Services = append(report.Services, status)
+	}
+
+	report.Orphaned = orphans(services, existing)
+	if ts.config.Tyk.Prune {
+		for _, apiID := range report.Orphaned {
+			status := APIStatus{APIID: apiID, Action: "deleted"}
+			if err := ts.DeleteAPIDefinition(ctx, existing[apiID]); err != nil {
+				status.State, status.Error = SyncStateError, err.Error()
+				log.Printf("Failed to prune Tyk API definition %s: %v", apiID, err)
+			} else {
+				status.State = SyncStateInSync
+				changed = true
+			}
+			report.Pruned = append(report.Pruned, status)
+		}
+	}
+
+	if changed {
+		if err := ts.ReloadAPIDefinitions(ctx); err != nil {
+			report.Error = err.Error()
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// compare returns the state of a service's definition among existing, and
+// the definition the service should have
+func (ts *TykService) compare(service ServiceAPI, existing map[string]*TykAPIDefinition) (APIStatus, *TykAPIDefinition) {
+	desired := ts.GetAPIDefinitionForService(service.Name, service.URL, service.ListenPath)
+	desired.ORGID = ts.orgID
+	desired.Active = true
+	status := APIStatus{Service: service.Name, APIID: desired.APIID}
+
+	current, ok := existing[desired.APIID]
+	if !ok {
+		status.State = SyncStateMissing
+		return status, desired
+	}
+	// The dashboard assigns the database ID, which updates address
+	desired.ID = current.ID
+
+	same, err := sameDefinition(desired, current)
+	switch {
+	case err != nil:
+		status.State, status.Error = SyncStateError, err.Error()
+	case same:
+		status.State = SyncStateInSync
+	default:
+		status.State = SyncStateDrifted
+	}
+	return status, desired
+}
+
+// sameDefinition compares two definitions field by field through their JSON
+func sameDefinition(a, b *TykAPIDefinition) (bool, error) {
+	aJSON, err := json.Marshal(a)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal API definition: %w", err)
+	}
+	bJSON, err := json.Marshal(b)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal API definition: %w", err)
+	}
+	return bytes.Equal(aJSON, bJSON), nil
+}
+
+// orphans returns the API IDs of the managed definitions no service declares
+func orphans(services []ServiceAPI, existing map[string]*TykAPIDefinition) []string {
+	declared := make(map[string]bool, len(services))
+	for _, service := range services {
+		declared[APIIDForService(service.Name)] = true
+	}
+
+	var orphaned []string
+	for apiID := range existing {
+		if strings.HasPrefix(apiID, managedAPIPrefix) && !declared[apiID] {
+			orphaned = append(orphaned, apiID)
+		}
+	}
+	sort.Strings(orphaned)
+	return orphaned
+}
+
+// ListAPIDefinitions fetches every API definition of the dashboard, by API ID
+func (ts *TykService) ListAPIDefinitions(ctx context.Context) (map[string]*TykAPIDefinition, error) {
+	definitions := make(map[string]*TykAPIDefinition)
+	for page := 1; ; page++ {
+		var list dashboardAPIList
+		if err := ts.dashboardRequest(ctx, http.MethodGet, fmt.Sprintf("/api/apis?p=%d", page), nil, &list); err != nil {
+			return nil, fmt.Errorf("failed to list API definitions: %w", err)
+		}
+		for _, api := range list.APIs {
+			if api.APIDefinition != nil {
+				definitions[api.APIDefinition.APIID] = api.APIDefinition
+			}
+		}
+		if page >= list.Pages {
+			return definitions, nil
+		}
+	}
+}
+
+// UpdateAPIDefinition replaces an API definition of the dashboard, found by
+// its database ID
+func (ts *TykService) UpdateAPIDefinition(ctx context.Context, apiDef *TykAPIDefinition) error {
+	if apiDef.ID == "" {
+		return fmt.Errorf("API definition %s has no dashboard ID", apiDef.APIID)
+	}
+	if err := ts.dashboardRequest(ctx, http.MethodPut, "/api/apis/"+apiDef.ID, dashboardAPI{APIDefinition: apiDef}, nil); err != nil {
+		return fmt.Errorf("API definition update failed: %w", err)
+	}
+	log.Printf("Successfully updated API definition: %s", apiDef.Name)
+	return nil
+}
+
+// DeleteAPIDefinition deletes an API definition of the dashboard
+func (ts *TykService) DeleteAPIDefinition(ctx context.Context, apiDef *TykAPIDefinition) error {
+	if err := ts.dashboardRequest(ctx, http.MethodDelete, "/api/apis/"+apiDef.ID, nil, nil); err != nil {
+		return fmt.Errorf("API definition deletion failed: %w", err)
+	}
+	log.Printf("Successfully deleted API definition: %s", apiDef.Name)
+	return nil
+}
+
+// dashboardRequest sends a request to the dashboard API, decoding the
+// response into out unless it is nil
+func (ts *TykService) dashboardRequest(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		jsonData, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, ts.dashboardURL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", ts.apiKey)
+
+	resp, err := ts.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%d - %s", resp.StatusCode, string(respBody))
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+// StatusEndpoint reports the sync state of every service's API definition
+func (ts *TykService) StatusEndpoint(services []ServiceAPI) gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		report, err := ts.Status(c.Request.Context(), services)
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{
+				"error": err.Error(),
+				"code":  "TYK_UNAVAILABLE",
+			})
+			return
+		}
+		c.JSON(http.StatusOK, report)
+	})
+}
+
+// SyncEndpoint runs a sync and reports what it did
+func (ts *TykService) SyncEndpoint(services []ServiceAPI) gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		report, err := ts.Sync(c.Request.Context(), services)
+		if report == nil {
+			c.JSON(http.StatusBadGateway, gin.H{
+				"error": err.Error(),
+				"code":  "TYK_UNAVAILABLE",
+			})
+			return
+		}
+
+		status := http.StatusOK
+		if err != nil || !report.InSync() {
+			status = http.StatusMultiStatus
+		}
+		c.JSON(status, report)
+	})
+}
@@ -0,0 +1,296 @@
+package tyk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Mir00r/X-Form-Backend/services/api-gateway/internal/config"
+)
+
+// fakeDashboard serves the part of the Tyk dashboard and gateway APIs the
+// sync uses, counting the writes
+type fakeDashboard struct {
+	mu      sync.Mutex
+	apis    map[string]*TykAPIDefinition // by dashboard ID
+	nextID  int
+	writes  int
+	reloads int
+	failPut bool
+}
+
+func newFakeDashboard(t *testing.T) (*fakeDashboard, *httptest.Server) {
+	t.Helper()
+	fake := &fakeDashboard{apis: make(map[string]*TykAPIDefinition)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakeDashboard) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "secret" && r.Header.Get("X-Tyk-Authorization") != "secret" {
+		http.Error(w, "unauthorized", http.StatusForbidden)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/api/apis/")
+	switch {
+	case r.URL.Path == "/tyk/reload":
+		f.reloads++
+	case r.URL.Path == "/api/apis" && r.Method == http.MethodGet:
+		// One definition per page, to exercise the paging
+		list := dashboardAPIList{Pages: len(f.apis)}
+		page := 0
+		fmt.Sscan(r.URL.Query().Get("p"), &page)
+		for _, apiDef := range f.sorted() {
+			if page--; page == 0 {
+				list.APIs = append(list.APIs, dashboardAPI{APIDefinition: apiDef})
+			}
+		}
+		json.NewEncoder(w).Encode(list)
+	case r.URL.Path == "/api/apis" && r.Method == http.MethodPost:
+		var body dashboardAPI
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.APIDefinition == nil {
+			http.Error(w, "bad definition", http.StatusBadRequest)
+			return
+		}
+		f.nextID++
+		body.APIDefinition.ID = fmt.Sprintf("db-%d", f.nextID)
+		f.apis[body.APIDefinition.ID] = body.APIDefinition
+		f.writes++
+	case f.apis[id] != nil && r.Method == http.MethodPut:
+		if f.failPut {
+			http.Error(w, "dashboard is read-only", http.StatusInternalServerError)
+			return
+		}
+		var body dashboardAPI
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.APIDefinition == nil {
+			http.Error(w, "bad definition", http.StatusBadRequest)
+			return
+		}
+		body.APIDefinition.ID = id
+		f.apis[id] = body.APIDefinition
+		f.writes++
+	case f.apis[id] != nil && r.Method == http.MethodDelete:
+		delete(f.apis, id)
+		f.writes++
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// sorted returns the definitions ordered by dashboard ID
+func (f *fakeDashboard) sorted() []*TykAPIDefinition {
+	var apis []*TykAPIDefinition
+	for i := 1; i <= f.nextID; i++ {
+		if apiDef := f.apis[fmt.Sprintf("db-%d", i)]; apiDef != nil {
+			apis = append(apis, apiDef)
+		}
+	}
+	return apis
+}
+
+// byAPIID returns the definition with the API ID
+func (f *fakeDashboard) byAPIID(apiID string) *TykAPIDefinition {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, apiDef := range f.apis {
+		if apiDef.APIID == apiID {
+			return apiDef
+		}
+	}
+	return nil
+}
+
+func (f *fakeDashboard) remove(apiID string) {
+	if apiDef := f.byAPIID(apiID); apiDef != nil {
+		f.mu.Lock()
+		delete(f.apis, apiDef.ID)
+		f.mu.Unlock()
+	}
+}
+
+func (f *fakeDashboard) add(apiDef *TykAPIDefinition) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	apiDef.ID = fmt.Sprintf("db-%d", f.nextID)
+	f.apis[apiDef.ID] = apiDef
+}
+
+func newTestService(serverURL string, prune bool) *TykService {
+	cfg := &config.Config{}
+	cfg.Tyk = config.TykConfig{
+		Enabled:      true,
+		GatewayURL:   serverURL,
+		DashboardURL: serverURL,
+		APIKey:       "secret",
+		OrgID:        "org",
+		Prune:        prune,
+	}
+	return NewTykService(cfg)
+}
+
+var testServices = []ServiceAPI{
+	{Name: "auth-service", URL: "http://auth-service:3001", ListenPath: "/api/v1/auth"},
+	{Name: "form-service", URL: "http://form-service:8001", ListenPath: "/api/v1/forms"},
+}
+
+func states(report *SyncReport) map[string]SyncState {
+	states := make(map[string]SyncState)
+	for _, status := range report.Services {
+		states[status.Service] = status.State
+	}
+	return states
+}
+
+func TestSyncIsIdempotent(t *testing.T) {
+	fake, server := newFakeDashboard(t)
+	ts := newTestService(server.URL, false)
+	ctx := context.Background()
+
+	status, err := ts.Status(ctx, testServices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, state := range states(status) {
+		if state != SyncStateMissing {
+			t.Errorf("%s is %s before the first sync, want missing", name, state)
+		}
+	}
+
+	report, err := ts.Sync(ctx, testServices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.InSync() || fake.writes != 2 || fake.reloads != 1 {
+		t.Fatalf("first sync = %+v with %d writes and %d reloads, want 2 creations and a reload", report, fake.writes, fake.reloads)
+	}
+	for _, s := range report.Services {
+		if s.Action != "created" {
+			t.Errorf("%s: action %q, want created", s.Service, s.Action)
+		}
+	}
+
+	// Nothing changed: no write, no reload, no duplicate
+	report, err = ts.Sync(ctx, testServices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.InSync() || fake.writes != 2 || fake.reloads != 1 || len(fake.apis) != 2 {
+		t.Errorf("second sync = %+v with %d writes, %d reloads and %d definitions", report, fake.writes, fake.reloads, len(fake.apis))
+	}
+	if status, err = ts.Status(ctx, testServices); err != nil || !status.InSync() {
+		t.Errorf("status after sync = %+v, %v; want in sync", status, err)
+	}
+}
+
+func TestSyncRepairsDriftAndMissingDefinitions(t *testing.T) {
+	fake, server := newFakeDashboard(t)
+	ts := newTestService(server.URL, false)
+	ctx := context.Background()
+	if _, err := ts.Sync(ctx, testServices); err != nil {
+		t.Fatal(err)
+	}
+
+	// Someone edits one definition in the dashboard and deletes the other
+	drifted := fake.byAPIID(APIIDForService("form-service"))
+	drifted.Proxy.TargetURL = "http://elsewhere:8001"
+	dbID := drifted.ID
+	fake.remove(APIIDForService("auth-service"))
+
+	status, err := ts.Status(ctx, testServices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := states(status); got["form-service"] != SyncStateDrifted || got["auth-service"] != SyncStateMissing {
+		t.Fatalf("states = %v, want form-service drifted and auth-service missing", got)
+	}
+
+	report, err := ts.Sync(ctx, testServices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.InSync() {
+		t.Fatalf("sync = %+v, want in sync", report)
+	}
+	repaired := fake.byAPIID(APIIDForService("form-service"))
+	if repaired.ID != dbID || repaired.Proxy.TargetURL != "http://form-service:8001" {
+		t.Errorf("form-service definition = %s at %s, want updated in place", repaired.ID, repaired.Proxy.TargetURL)
+	}
+	if len(fake.apis) != 2 {
+		t.Errorf("%d definitions, want 2", len(fake.apis))
+	}
+}
+
+func TestSyncPrunesOnlyWhenEnabled(t *testing.T) {
+	fake, server := newFakeDashboard(t)
+	ctx := context.Background()
+	fake.add(&TykAPIDefinition{APIID: APIIDForService("legacy-service"), Name: "X-Form legacy Service"})
+	fake.add(&TykAPIDefinition{APIID: "billing-api", Name: "Not ours"})
+
+	report, err := newTestService(server.URL, false).Sync(ctx, testServices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Orphaned) != 1 || report.Orphaned[0] != "x-form-legacy-service-api" || len(report.Pruned) != 0 {
+		t.Errorf("sync without pruning = %+v, want the legacy definition reported and kept", report)
+	}
+	if report.InSync() || fake.byAPIID("x-form-legacy-service-api") == nil {
+		t.Error("an orphaned definition was deleted without pruning")
+	}
+
+	report, err = newTestService(server.URL, true).Sync(ctx, testServices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Pruned) != 1 || report.Pruned[0].Action != "deleted" || !report.InSync() {
+		t.Errorf("sync with pruning = %+v", report)
+	}
+	if fake.byAPIID("x-form-legacy-service-api") != nil {
+		t.Error("the orphaned definition was not pruned")
+	}
+	if fake.byAPIID("billing-api") == nil {
+		t.Error("pruning deleted a definition the gateway does not manage")
+	}
+}
+
+func TestSyncReportsErrors(t *testing.T) {
+	fake, server := newFakeDashboard(t)
+	ts := newTestService(server.URL, false)
+	ctx := context.Background()
+	if _, err := ts.Sync(ctx, testServices); err != nil {
+		t.Fatal(err)
+	}
+
+	fake.byAPIID(APIIDForService("auth-service")).Proxy.ListenPath = "/old"
+	fake.failPut = true
+	report, err := ts.Sync(ctx, testServices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := states(report); got["auth-service"] != SyncStateError || got["form-service"] != SyncStateInSync {
+		t.Errorf("states = %v, want auth-service failed and form-service in sync", got)
+	}
+	if report.InSync() {
+		t.Error("a failed update reported in sync")
+	}
+
+	// A dashboard that cannot be reached fails the whole sync
+	ts = newTestService(server.URL, false)
+	ts.apiKey = "wrong"
+	if _, err := ts.Status(ctx, testServices); err == nil {
+		t.Error("status succeeded without access to the dashboard")
+	}
+	if _, err := ts.Sync(ctx, testServices); err == nil {
+		t.Error("sync succeeded without access to the dashboard")
+	}
+}
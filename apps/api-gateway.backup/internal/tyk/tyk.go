@@ -8,6 +8,7 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/Mir00r/X-Form-Backend/services/api-gateway/internal/config"
@@ -22,6 +23,9 @@ type TykService struct {
 	gatewayURL   string
 	apiKey       string
 	orgID        string
+
+	// syncMu keeps a manual sync from interleaving with the startup one
+	syncMu sync.Mutex
 }
 
 // NewTykService creates a new Tyk service instance
@@ -615,28 +619,8 @@ func (ts *TykService) CreateAPIDefinition(ctx context.Context, apiDef *TykAPIDef
 	apiDef.ORGID = ts.orgID
 	apiDef.Active = true
 
-	jsonData, err := json.Marshal(apiDef)
-	if err != nil {
-		return fmt.Errorf("failed to marshal API definition: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", ts.dashboardURL+"/api/apis", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", ts.apiKey)
-
-	resp, err := ts.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API definition creation failed: %d - %s", resp.StatusCode, string(body))
+	if err := ts.dashboardRequest(ctx, http.MethodPost, "/api/apis", dashboardAPI{APIDefinition: apiDef}, nil); err != nil {
+		return fmt.Errorf("API definition creation failed: %w", err)
 	}
 
 	log.Printf("Successfully created API definition: %s", apiDef.Name)
@@ -744,8 +728,8 @@ func (ts *TykService) CreatePolicy(ctx context.Context, policy *TykPolicy) (stri
 func (ts *TykService) GetAPIDefinitionForService(serviceName, serviceURL, listenPath string) *TykAPIDefinition {
 	return &TykAPIDefinition{
 		Name:             fmt.Sprintf("X-Form %s Service", serviceName),
-		Slug:             fmt.Sprintf("x-form-%s", serviceName),
-		APIID:            fmt.Sprintf("x-form-%s-api", serviceName),
+		Slug:             managedAPIPrefix + serviceName,
+		APIID:            APIIDForService(serviceName),
 		UseKeylessAccess: false,
 		UseOauth2:        false,
 		UseOpenID:        true,
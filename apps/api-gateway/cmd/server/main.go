@@ -11,17 +11,30 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/apikey"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/audit"
 	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/config"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/drain"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/faults"
 	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/handler"
 	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/mtls"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/policy"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/privacy"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/routes"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/shed"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/validator"
 	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
 	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/metrics"
+	"github.com/Mir00r/X-Form-Backend/shared/errreport"
+	"github.com/Mir00r/X-Form-Backend/shared/flags"
 
 	// Import docs package for swagger
-	_ "github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/docs"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/docs"
 )
 
 // HealthResponse represents the health check response
@@ -59,6 +72,10 @@ type GatewayInfoResponse struct {
 // @in header
 // @name Authorization
 
+// @securityDefinitions.apikey IntegrationKeyAuth
+// @in header
+// @name X-API-Key
+
 func main() {
 	// Initialize configuration
 	cfg, err := config.Load()
@@ -90,9 +107,189 @@ func main() {
 		EnableProcessMetrics: true,
 	}
 	metrics := metrics.NewCollector(metricsConfig)
+	metrics.StartSystemMetricsCollector(15 * time.Second)
+
+	// Initialize maintenance mode, shared by all replicas through Redis
+	maintenanceStore, err := middleware.NewMaintenanceStore(cfg.Maintenance, logger)
+	if err != nil {
+		logger.Fatalf("Failed to initialize maintenance store: %v", err)
+	}
+	defer maintenanceStore.Close()
+
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	maintenanceStore.Start(workerCtx)
+
+	// Initialize API keys for service-to-service and integration clients
+	apiKeyStore, err := apikey.NewRedisStore(cfg.Security.APIKeys.RedisURL)
+	if err != nil {
+		logger.Fatalf("Failed to initialize API key store: %v", err)
+	}
+	defer apiKeyStore.Close()
+	apiKeys := apikey.NewService(apiKeyStore, cfg.Security.APIKeys.CacheTTL)
+
+	// Initialize data subject requests, carried out by the services through
+	// the event bus
+	privacyStore, err := privacy.NewRedisStore(cfg.Privacy.RedisURL)
+	if err != nil {
+		logger.Fatalf("Failed to initialize privacy request store: %v", err)
+	}
+	defer privacyStore.Close()
+	privacyRequests := privacy.NewService(privacyStore,
+		privacy.NewEventBusPublisher(cfg.Privacy.EventBusURL, cfg.Privacy.Timeout), cfg.Privacy, logger)
+	if cfg.Privacy.CallbackToken == "" {
+		logger.Warnf("privacy.callback_token is not set; privacy requests will not complete")
+	}
+	if cfg.FormAdmin.ServiceToken == "" {
+		logger.Warnf("form_admin.service_token is not set; the form service will refuse form cleanups and cache purges")
+	}
+
+	// Evaluate feature flags against a snapshot of the flags in Redis, shared
+	// with the services
+	flagOpts, err := redis.ParseURL(cfg.FeatureFlags.RedisURL)
+	if err != nil {
+		logger.Fatalf("Failed to initialize feature flags: %v", err)
+	}
+	flagRedis := redis.NewClient(flagOpts)
+	defer flagRedis.Close()
+	definedFlags, err := flags.Parse(cfg.FeatureFlags.Flags)
+	if err != nil {
+		logger.Fatalf("Failed to initialize feature flags: %v", err)
+	}
+	featureFlags := flags.NewClient(flags.NewRedisStore(flagRedis, cfg.FeatureFlags.RedisKey), definedFlags)
+	if err := featureFlags.Refresh(workerCtx); err != nil {
+		logger.Warnf("Feature flags changed at runtime are not loaded yet: %v", err)
+	}
+	go featureFlags.Run(workerCtx, cfg.FeatureFlags.RefreshInterval, func(err error) {
+		logger.Warnf("Failed to refresh feature flags: %v", err)
+	})
+	flags.SetDefault(featureFlags)
+
+	// Record admin actions to the audit log of the event bus
+	var auditRecorder *audit.Recorder
+	if cfg.Audit.Enabled {
+		auditRecorder = audit.NewRecorder(cfg.Audit, metrics, logger)
+		auditRecorder.Start()
+	}
+
+	// Load the OpenAPI document used for request validation
+	var specValidator *validator.OpenAPIValidator
+	if cfg.Validation.OpenAPI.Enabled {
+		specValidator, err = loadOpenAPIValidator(cfg.Validation.OpenAPI.SpecPath)
+		if err != nil {
+			logger.Fatalf("Failed to load OpenAPI document: %v", err)
+		}
+	}
+
+	// Resolve the route policies; an invalid policy stops the gateway from
+	// starting rather than applying defaults silently
+	policies, err := policy.NewResolver(cfg.Policies, cfg.Security.RateLimit)
+	if err != nil {
+		logger.Fatalf("Invalid route policies:\n%v", err)
+	}
+
+	// Shed low-priority traffic while the gateway is overloaded
+	var shedder *shed.Shedder
+	if cfg.LoadShedding.Enabled {
+		shedder = shed.New(cfg.LoadShedding, metrics)
+		shedder.Start(workerCtx)
+	}
+
+	// Load the client certificate for mTLS to the internal services, reloaded
+	// when it is rotated
+	var upstreamTLS *mtls.Source
+	if cfg.Security.MTLS.Enabled {
+		upstreamTLS, err = mtls.NewSource(cfg.Security.MTLS, logger)
+		if err != nil {
+			logger.Fatalf("Failed to load mTLS certificates: %v", err)
+		}
+		upstreamTLS.Start(workerCtx)
+	}
+
+	// Report panics and upstream failures to the error tracker
+	errorReporter, err := errreport.New(errreport.Config{
+		Provider:    cfg.ErrorReporting.Provider,
+		Service:     "api-gateway",
+		Environment: cfg.Environment,
+		Release:     "api-gateway@" + cfg.Version,
+		DSN:         cfg.ErrorReporting.DSN,
+		Burst:       cfg.ErrorReporting.Burst,
+		Interval:    cfg.ErrorReporting.Interval,
+		SampleRate:  cfg.ErrorReporting.SampleRate,
+	})
+	if err != nil {
+		logger.Fatalf("Failed to initialize error reporting: %v", err)
+	}
 
 	// Initialize handler with service discovery and circuit breakers
-	handler := handler.NewHandler(cfg, logger, metrics)
+	gatewayHandler := handler.NewHandler(cfg, logger, metrics, upstreamTLS)
+
+	// Purge the cached views of forms as they change, announced by the event
+	// bus to every replica
+	if invalidation := cfg.Proxy.CacheInvalidation; invalidation.Enabled {
+		if invalidator := gatewayHandler.NewCacheInvalidator(invalidation.CoalesceWindow); invalidator != nil {
+			invalidationOpts, err := redis.ParseURL(invalidation.RedisURL)
+			if err != nil {
+				logger.Fatalf("Failed to initialize cache invalidation: %v", err)
+			}
+			invalidationRedis := redis.NewClient(invalidationOpts)
+			defer invalidationRedis.Close()
+			go invalidator.Run(workerCtx, invalidationRedis, invalidation.Channel)
+		}
+	}
+
+	// Route to the healthy instances of the services, checked in the
+	// background
+	serviceRegistry := middleware.NewServiceRegistry(logger, metrics)
+
+	// Health overrides pinned by admins, kept in Redis for every replica
+	registryOpts, err := redis.ParseURL(cfg.Registry.RedisURL)
+	if err != nil {
+		logger.Fatalf("Failed to initialize registry health overrides: %v", err)
+	}
+	registryRedis := redis.NewClient(registryOpts)
+	defer registryRedis.Close()
+	serviceRegistry.SyncOverrides(workerCtx, middleware.NewRedisOverrideStore(registryRedis, cfg.Registry.Key), cfg.Registry.RefreshInterval)
+
+	// Rate limit counters, reset by admins clearing the rate_limits cache
+	var rateLimitRedis *redis.Client
+	if cfg.Security.RateLimit.RedisURL != "" {
+		rateLimitOpts, err := redis.ParseURL(cfg.Security.RateLimit.RedisURL)
+		if err != nil {
+			logger.Fatalf("Failed to initialize rate limit counters: %v", err)
+		}
+		rateLimitRedis = redis.NewClient(rateLimitOpts)
+		defer rateLimitRedis.Close()
+	}
+
+	// Requests are counted against their rate limit by one limiter shared
+	// by every request, which also reports the limits of callers
+	rateLimiter := middleware.NewRateLimiter(cfg.Security.RateLimit)
+
+	// Fault injection, for resilience testing outside production only
+	var injector *faults.Injector
+	if cfg.FaultInjection.Enabled && !cfg.IsProduction() {
+		injector = faults.New(cfg.FaultInjection, metrics, logger)
+		logger.Warn("Fault injection is enabled")
+	}
+
+	admin := adminHandlers{
+		maintenance: handler.NewAdminHandler(maintenanceStore, auditRecorder, logger),
+		stats:       handler.NewStatsHandler(gatewayHandler, metrics),
+		apiKeys:     handler.NewAPIKeyHandler(apiKeys, cfg.Security.APIKeys.RotationGracePeriod, auditRecorder, logger),
+		policies:    handler.NewPolicyHandler(policies),
+		privacy:     handler.NewPrivacyHandler(privacyRequests, cfg.Privacy.CallbackToken, auditRecorder, logger),
+		audit:       handler.NewAuditHandler(cfg.Audit.EventBusURL, cfg.Audit.Timeout, logger),
+		forms:       handler.NewFormAdminHandler(cfg.FormAdmin.FormServiceURL, cfg.FormAdmin.ServiceToken, cfg.FormAdmin.Timeout, auditRecorder, logger),
+		flags:       handler.NewFlagHandler(featureFlags, auditRecorder, logger),
+		caches: handler.NewCacheAdminHandler(gatewayHandler, apiKeys, serviceRegistry, rateLimitRedis,
+			cfg.FormAdmin.FormServiceURL, cfg.FormAdmin.ServiceToken, cfg.FormAdmin.Timeout, cfg.CacheClear.MinInterval, auditRecorder, logger),
+		registry: handler.NewRegistryHandler(serviceRegistry, auditRecorder, logger),
+		limits:   handler.NewLimitsHandler(rateLimiter, policies, cfg.FormAdmin.FormServiceURL, cfg.FormAdmin.Timeout, logger),
+	}
+	if injector != nil {
+		admin.faults = handler.NewFaultHandler(injector, cfg.FaultInjection.Token, auditRecorder, logger)
+	}
 
 	// Set Gin mode based on environment
 	if cfg.Environment != "development" {
@@ -102,11 +299,16 @@ func main() {
 	// Create Gin router
 	router := gin.New()
 
+	// Readiness turns to 503 as soon as shutdown starts, so the load
+	// balancer stops routing here while in-flight requests drain
+	drainer := drain.New(cfg.Server.Shutdown, logger)
+	router.GET("/ready", gin.WrapF(drainer.ReadinessHandler))
+
 	// Setup comprehensive middleware chain following the 7-step architecture
-	setupMiddlewareChain(router, cfg, logger, metrics)
+	setupMiddlewareChain(router, cfg, logger, metrics, errorReporter, maintenanceStore, apiKeys, serviceRegistry, specValidator, policies, shedder, injector, rateLimiter)
 
 	// Setup routes with full API Gateway functionality
-	setupRoutes(router, handler, cfg, logger, metrics)
+	setupRoutes(router, gatewayHandler, admin, cfg, logger, metrics)
 
 	// Get port from environment or config
 	port := os.Getenv("PORT")
@@ -114,10 +316,11 @@ func main() {
 		port = "8000" // Use port 8000 for tests
 	}
 
-	// Create HTTP server
+	// Create HTTP server. Every response carries the request ID, trace
+	// context and Server-Timing of the gateway, whichever path answers it.
 	server := &http.Server{
 		Addr:         ":" + port,
-		Handler:      router,
+		Handler:      drainer.Track(http.HandlerFunc(middleware.RequestID()(router.ServeHTTP))),
 		ReadTimeout:  time.Duration(cfg.Server.ReadTimeout) * time.Second,
 		WriteTimeout: time.Duration(cfg.Server.WriteTimeout) * time.Second,
 		IdleTimeout:  time.Duration(cfg.Server.IdleTimeout) * time.Second,
@@ -145,25 +348,54 @@ func main() {
 
 	logger.Infof("🛑 Shutting down Enhanced API Gateway...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	// Keep serving through the pre-stop delay, drain the requests in flight,
+	// then release the idle connections to the services
+	drainer.Shutdown(server, gatewayHandler.CloseIdleConnections)
 
-	if err := server.Shutdown(ctx); err != nil {
-		logger.Fatalf("Server forced to shutdown: %v", err)
+	// Publish the audit events recorded by the last requests
+	if auditRecorder != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Audit.Timeout)
+		auditRecorder.Stop(ctx)
+		cancel()
 	}
+	errorReporter.Flush(5 * time.Second)
 
 	logger.Infof("✅ Enhanced API Gateway exited gracefully")
 }
 
 // setupMiddlewareChain configures the comprehensive middleware chain
 // Implements the 7-step API Gateway process from the architecture diagram
-func setupMiddlewareChain(router *gin.Engine, cfg *config.Config, logger logger.Logger, metrics *metrics.Collector) {
-	// Initialize service registry for Step 5
-	serviceRegistry := middleware.NewServiceRegistry(logger, metrics)
-
+func setupMiddlewareChain(router *gin.Engine, cfg *config.Config, logger logger.Logger, metrics *metrics.Collector, errorReporter errreport.Reporter, maintenanceStore *middleware.MaintenanceStore, apiKeys *apikey.Service, serviceRegistry *middleware.ServiceRegistry, specValidator *validator.OpenAPIValidator, policies *policy.Resolver, shedder *shed.Shedder, injector *faults.Injector, rateLimiter *middleware.RateLimiter) {
 	// Step 1: Parameter Validation
 	router.Use(gin.Logger())
-	router.Use(gin.Recovery())
+
+	// Panics are reported with the route of the request, and answered with
+	// the ID of the event
+	router.Use(ginMiddleware(middleware.Recovery(logger, errorReporter)))
+	router.Use(func(c *gin.Context) {
+		middleware.SetErrorRoute(c.Request.Context(), c.FullPath())
+		c.Next()
+	})
+
+	// Request metrics, recorded before any step can reject the request
+	router.Use(func(c *gin.Context) {
+		start := time.Now()
+		metrics.IncrementRequestsInFlight()
+		defer metrics.DecrementRequestsInFlight()
+
+		c.Next()
+
+		// Label by route pattern to keep the label cardinality bounded
+		path := c.FullPath()
+		if path == "" {
+			path = "unmatched"
+		}
+		size := c.Writer.Size()
+		if size < 0 {
+			size = 0
+		}
+		metrics.RecordHTTPRequestWithFault(c.Request.Method, path, c.Writer.Status(), time.Since(start), int64(size), faults.Injected(c.Request.Context()))
+	})
 
 	// Step 2: Whitelist Validation
 	router.Use(func(c *gin.Context) {
@@ -178,36 +410,51 @@ func setupMiddlewareChain(router *gin.Engine, cfg *config.Config, logger logger.
 		})(w, r)
 	})
 
+	// The policy of the route is attached to the request for the rate limit
+	// and the proxy, and its body size limit enforced
+	router.Use(ginMiddleware(middleware.PolicyResolver(policies)))
+
+	// Fault injection rules matching the path are attached for the proxy
+	if injector != nil {
+		router.Use(ginMiddleware(middleware.FaultInjection(injector)))
+	}
+
+	// Maintenance mode, checked against the locally cached flag
+	router.Use(ginMiddleware(middleware.Maintenance(maintenanceStore, cfg.Maintenance, cfg.Security.JWT)))
+
 	// Step 3: Authentication & Authorization
+	// API keys are checked first; requests without one fall through to JWT
+	router.Use(ginMiddleware(middleware.APIKeyAuth(apiKeys)))
+	authMiddleware := ginMiddleware(middleware.Authentication(cfg.Security.JWT))
 	router.Use(func(c *gin.Context) {
-		w := c.Writer
 		r := c.Request
 
-		// Skip auth for health and docs endpoints
-		if r.URL.Path == "/health" || r.URL.Path == "/metrics" ||
-			r.URL.Path == "/swagger" || r.URL.Path == "/" {
+		// Skip auth for health and docs endpoints, and for the privacy
+		// callback, which checks a token of its own
+		if r.URL.Path == "/health" || r.URL.Path == "/ready" || r.URL.Path == "/metrics" ||
+			r.URL.Path == "/swagger" || r.URL.Path == "/" || r.URL.Path == "/internal/privacy/completions" {
 			c.Next()
 			return
 		}
 
-		// Apply authentication middleware - using existing Authentication function
-		authMiddleware := middleware.Authentication(cfg.Security.JWT)
-		authMiddleware(func(w http.ResponseWriter, r *http.Request) {
-			c.Next()
-		})(w, r)
+		// Apply authentication middleware - the authenticated user is carried
+		// in the request context for later steps
+		authMiddleware(c)
 	})
 
-	// Step 4: Rate Limiting
-	router.Use(func(c *gin.Context) {
-		w := c.Writer
-		r := c.Request
+	// Load shedding, once the caller is known so anonymous requests go first
+	if shedder != nil {
+		router.Use(ginMiddleware(middleware.LoadShedding(shedder)))
+	}
 
-		// Apply rate limiting middleware
-		rateLimitMiddleware := middleware.RateLimit(cfg.Security.RateLimit)
-		rateLimitMiddleware(func(w http.ResponseWriter, r *http.Request) {
-			c.Next()
-		})(w, r)
-	})
+	// Requests under the configured route groups are validated against the
+	// OpenAPI document once the caller is known
+	if specValidator != nil {
+		router.Use(ginMiddleware(middleware.OpenAPIValidation(specValidator, cfg.Validation.OpenAPI.RouteGroups)))
+	}
+
+	// Step 4: Rate Limiting
+	router.Use(ginMiddleware(rateLimiter.Middleware()))
 
 	// Step 5: Service Discovery
 	router.Use(func(c *gin.Context) {
@@ -234,8 +481,57 @@ func setupMiddlewareChain(router *gin.Engine, cfg *config.Config, logger logger.
 	})
 }
 
+// loadOpenAPIValidator compiles the document at specPath, or the generated
+// gateway docs when no path is configured
+func loadOpenAPIValidator(specPath string) (*validator.OpenAPIValidator, error) {
+	document := []byte(docs.SwaggerInfo.ReadDoc())
+	if specPath != "" {
+		var err error
+		if document, err = os.ReadFile(specPath); err != nil {
+			return nil, err
+		}
+	}
+	return validator.NewOpenAPIValidator(document)
+}
+
+// ginMiddleware adapts a net/http style middleware to gin. The request passed
+// on by the middleware replaces the gin request, and the chain is aborted when
+// the middleware handles the request itself or recovers a panic of the chain.
+func ginMiddleware(m middleware.Middleware) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		completed := false
+		m(func(w http.ResponseWriter, r *http.Request) {
+			c.Request = r
+			c.Next()
+			completed = true
+		})(c.Writer, c.Request)
+
+		if !completed {
+			c.Abort()
+		}
+	}
+}
+
+// adminHandlers groups the administrative handlers
+type adminHandlers struct {
+	maintenance *handler.AdminHandler
+	stats       *handler.StatsHandler
+	apiKeys     *handler.APIKeyHandler
+	policies    *handler.PolicyHandler
+	privacy     *handler.PrivacyHandler
+	audit       *handler.AuditHandler
+	forms       *handler.FormAdminHandler
+	flags       *handler.FlagHandler
+	caches      *handler.CacheAdminHandler
+	registry    *handler.RegistryHandler
+	// faults is nil unless fault injection is enabled
+	faults *handler.FaultHandler
+	// limits reports their limits to callers
+	limits *handler.LimitsHandler
+}
+
 // setupRoutes sets up all the routes for the API Gateway
-func setupRoutes(router *gin.Engine, h *handler.Handler, cfg *config.Config, logger logger.Logger, metrics *metrics.Collector) {
+func setupRoutes(router *gin.Engine, h *handler.Handler, admin adminHandlers, cfg *config.Config, logger logger.Logger, metrics *metrics.Collector) {
 	// Swagger documentation
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
@@ -263,67 +559,71 @@ func setupRoutes(router *gin.Engine, h *handler.Handler, cfg *config.Config, log
 		v1.GET("/metrics", func(c *gin.Context) {
 			metricsHandler(c, metrics)
 		})
-	}
+		v1.GET("/limits", admin.limits.GetLimits)
 
-	// Service proxy routes with full API Gateway functionality
-	setupServiceRoutes(router, h)
-}
+		adminGroup := v1.Group("/admin", ginMiddleware(middleware.AdminRequired()))
+		{
+			adminGroup.GET("/maintenance", admin.maintenance.GetMaintenanceMode)
+			adminGroup.PUT("/maintenance", admin.maintenance.SetMaintenanceMode)
+			adminGroup.GET("/stats", admin.stats.GetSystemStats)
+			adminGroup.GET("/performance", admin.stats.GetPerformanceMetrics)
 
-// setupServiceRoutes configures routes that proxy to backend services
-func setupServiceRoutes(router *gin.Engine, h *handler.Handler) {
-	// Auth service routes
-	authGroup := router.Group("/auth")
-	{
-		authGroup.Any("/*path", func(c *gin.Context) {
-			h.ProxyToService(c.Writer, c.Request, "auth-service")
-		})
-	}
+			adminGroup.POST("/api-keys", admin.apiKeys.CreateAPIKey)
+			adminGroup.GET("/api-keys", admin.apiKeys.ListAPIKeys)
+			adminGroup.POST("/api-keys/:id/rotate", admin.apiKeys.RotateAPIKey)
+			adminGroup.DELETE("/api-keys/:id", admin.apiKeys.RevokeAPIKey)
 
-	// Form service routes
-	formGroup := router.Group("/forms")
-	{
-		formGroup.Any("/*path", func(c *gin.Context) {
-			h.ProxyToService(c.Writer, c.Request, "form-service")
-		})
-	}
+			adminGroup.POST("/privacy/erasure", admin.privacy.RequestErasure)
+			adminGroup.POST("/privacy/export", admin.privacy.RequestExport)
+			adminGroup.GET("/privacy/requests/:id", admin.privacy.GetPrivacyRequest)
 
-	// Response service routes
-	responseGroup := router.Group("/responses")
-	{
-		responseGroup.Any("/*path", func(c *gin.Context) {
-			h.ProxyToService(c.Writer, c.Request, "response-service")
-		})
-	}
+			adminGroup.GET("/audit", admin.audit.QueryAuditLog)
+			adminGroup.GET("/audit/verify", admin.audit.VerifyAuditLog)
 
-	// Analytics service routes
-	analyticsGroup := router.Group("/analytics")
-	{
-		analyticsGroup.Any("/*path", func(c *gin.Context) {
-			h.ProxyToService(c.Writer, c.Request, "analytics-service")
-		})
-	}
+			adminGroup.POST("/forms/cleanup", admin.forms.CleanupForms)
+			adminGroup.GET("/forms/cleanup/:id", admin.forms.GetCleanupJob)
+			adminGroup.DELETE("/forms/cleanup/:id", admin.forms.CancelCleanupJob)
+			adminGroup.GET("/usage/:orgId", admin.forms.GetUsage)
 
-	// Collaboration service routes
-	collaborationGroup := router.Group("/collaboration")
-	{
-		collaborationGroup.Any("/*path", func(c *gin.Context) {
-			h.ProxyToService(c.Writer, c.Request, "collaboration-service")
-		})
+			adminGroup.GET("/flags", admin.flags.ListFlags)
+			adminGroup.PUT("/flags", admin.flags.SetFlag)
+
+			adminGroup.POST("/cache/clear", admin.caches.ClearCache)
+		}
 	}
 
-	// Realtime service routes
-	realtimeGroup := router.Group("/realtime")
-	{
-		realtimeGroup.Any("/*path", func(c *gin.Context) {
-			h.ProxyToService(c.Writer, c.Request, "realtime-service")
-		})
+	// Completions of privacy requests, posted back by the event bus with the
+	// callback token
+	router.POST("/internal/privacy/completions", admin.privacy.CompletePrivacyRequest)
+
+	// Gateway introspection
+	router.GET("/api/gateway/policies", ginMiddleware(middleware.AdminRequired()), admin.policies.GetPolicies)
+	router.GET("/api/gateway/registry", ginMiddleware(middleware.AdminRequired()), admin.registry.GetRegistry)
+	router.POST("/api/gateway/registry/:instanceId/health", ginMiddleware(middleware.AdminRequired()), admin.registry.SetInstanceHealth)
+	if admin.faults != nil {
+		router.GET("/api/gateway/faults", ginMiddleware(middleware.AdminRequired()), admin.faults.ListFaults)
+		router.PUT("/api/gateway/faults", ginMiddleware(middleware.AdminRequired()), admin.faults.SetFaults)
 	}
 
-	// Event bus service routes
-	eventGroup := router.Group("/events")
-	{
-		eventGroup.Any("/*path", func(c *gin.Context) {
-			h.ProxyToService(c.Writer, c.Request, "event-bus-service")
+	// Service proxy routes with full API Gateway functionality
+	setupServiceRoutes(router, h)
+}
+
+// setupServiceRoutes configures routes that proxy to backend services. The
+// prefixes, where they land upstream and the routes open to anonymous callers
+// are declared in the routes package, which the contract tests check against
+// the route manifests of the services.
+func setupServiceRoutes(router *gin.Engine, h *handler.Handler) {
+	for i := range routes.Proxies {
+		proxy := &routes.Proxies[i]
+
+		// API keys reach services without a dedicated scope only with the admin scope
+		group := router.Group(proxy.Prefix, ginMiddleware(middleware.RequireScope(proxy.ReadScope, proxy.WriteScope)))
+		group.Any("/*path", func(c *gin.Context) {
+			auth := proxy.AuthFor(c.Request.Method, c.Request.URL.Path)
+			c.Request.URL.Path = proxy.UpstreamPath(c.Request.URL.Path)
+			c.Request.URL.RawPath = ""
+			h.ProxyRoute(c.Writer, c.Request, proxy.Service, auth)
 		})
 	}
 }
@@ -3,6 +3,8 @@ module github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway
 go 1.21.0
 
 require (
+	github.com/Mir00r/X-Form-Backend/shared/errreport v0.0.0
+	github.com/Mir00r/X-Form-Backend/shared/flags v0.0.0
 	github.com/gin-gonic/gin v1.9.1
 	github.com/golang-jwt/jwt/v5 v5.3.0
 	github.com/prometheus/client_golang v1.16.0
@@ -19,6 +21,7 @@ require (
 	github.com/bytedance/sonic v1.9.1 // indirect
 	github.com/cespare/xxhash/v2 v2.3.0 // indirect
 	github.com/chenzhuoyu/base64x v0.0.0-20221115062448-fe3a3abad311 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
 	github.com/fsnotify/fsnotify v1.6.0 // indirect
 	github.com/gabriel-vasile/mimetype v1.4.2 // indirect
@@ -68,3 +71,7 @@ require (
 	gopkg.in/yaml.v2 v2.4.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace github.com/Mir00r/X-Form-Backend/shared/flags => ../../shared/flags
+
+replace github.com/Mir00r/X-Form-Backend/shared/errreport => ../../shared/errreport
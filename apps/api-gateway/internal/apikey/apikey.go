@@ -0,0 +1,362 @@
+// Package apikey issues and validates API keys for service-to-service and
+// integration clients. Only SHA-256 hashes of the secrets are stored.
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scopes an API key can be granted
+const (
+	ScopeReadForms      = "read:forms"
+	ScopeWriteForms     = "write:forms"
+	ScopeReadResponses  = "read:responses"
+	ScopeWriteResponses = "write:responses"
+	ScopeAdmin          = "admin"
+)
+
+// secretPrefix marks API key secrets so they are easy to recognise in logs and scanners
+const secretPrefix = "xfk_"
+
+var validScopes = map[string]bool{
+	ScopeReadForms:      true,
+	ScopeWriteForms:     true,
+	ScopeReadResponses:  true,
+	ScopeWriteResponses: true,
+	ScopeAdmin:          true,
+}
+
+var (
+	// ErrNotFound is returned when a key does not exist
+	ErrNotFound = errors.New("api key not found")
+	// ErrInvalidKey is returned when a secret is unknown, revoked or expired
+	ErrInvalidKey = errors.New("invalid api key")
+	// ErrInvalidRequest is returned when a key can't be issued as requested
+	ErrInvalidRequest = errors.New("invalid api key request")
+)
+
+// APIKey is the stored representation of a key. The secret itself is never stored.
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Hash       string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  string     `json:"created_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RotatedAt  *time.Time `json:"rotated_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+
+	// The hash the key had before its last rotation, accepted until PreviousValidUntil
+	PreviousHash       string     `json:"-"`
+	PreviousValidUntil *time.Time `json:"previous_valid_until,omitempty"`
+}
+
+// storedKey carries the fields hidden from API responses through the store
+type storedKey struct {
+	APIKey
+	Hash         string `json:"hash"`
+	PreviousHash string `json:"previous_hash,omitempty"`
+}
+
+// HasScope reports whether the key grants scope. The admin scope grants everything.
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// Usable reports whether the key may authenticate requests at the given time
+func (k *APIKey) Usable(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// Store persists API keys
+type Store interface {
+	Save(ctx context.Context, key *APIKey) error
+	Get(ctx context.Context, id string) (*APIKey, error)
+	// FindByHash resolves a current or still-valid previous hash to its key
+	FindByHash(ctx context.Context, hash string) (*APIKey, error)
+	List(ctx context.Context) ([]*APIKey, error)
+	// IndexHash makes hash resolve to id, for ttl when ttl > 0
+	IndexHash(ctx context.Context, hash, id string, ttl time.Duration) error
+	RemoveHash(ctx context.Context, hash string) error
+	TouchLastUsed(ctx context.Context, id string, at time.Time) error
+}
+
+// CreateRequest describes a key to issue
+type CreateRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// Service manages the API key lifecycle
+type Service struct {
+	store    Store
+	cacheTTL time.Duration
+	now      func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cachedKey
+	// lastTouch throttles last-used writes to one per key per minute
+	lastTouch map[string]time.Time
+}
+
+type cachedKey struct {
+	key       *APIKey
+	expiresAt time.Time
+}
+
+// NewService creates a new API key service. Successful lookups are cached in
+// memory for cacheTTL, which bounds how long a revoked key keeps working on
+// other gateway replicas.
+func NewService(store Store, cacheTTL time.Duration) *Service {
+	return &Service{
+		store:     store,
+		cacheTTL:  cacheTTL,
+		now:       time.Now,
+		cache:     make(map[string]cachedKey),
+		lastTouch: make(map[string]time.Time),
+	}
+}
+
+// Create issues a new key and returns it along with its secret. The secret
+// can't be recovered afterwards.
+func (s *Service) Create(ctx context.Context, req CreateRequest, createdBy string) (*APIKey, string, error) {
+	for _, scope := range req.Scopes {
+		if !validScopes[scope] {
+			return nil, "", fmt.Errorf("%w: unknown scope %s", ErrInvalidRequest, scope)
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(s.now()) {
+		return nil, "", fmt.Errorf("%w: expires_at must be in the future", ErrInvalidRequest)
+	}
+
+	id, err := randomHex(8)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := newSecret(id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	key := &APIKey{
+		ID:        id,
+		Name:      req.Name,
+		Prefix:    secretPrefix + id,
+		Hash:      HashSecret(secret),
+		Scopes:    req.Scopes,
+		CreatedBy: createdBy,
+		CreatedAt: s.now().UTC(),
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := s.store.Save(ctx, key); err != nil {
+		return nil, "", err
+	}
+	if err := s.store.IndexHash(ctx, key.Hash, key.ID, 0); err != nil {
+		return nil, "", err
+	}
+
+	return key, secret, nil
+}
+
+// List returns every key. Secrets and hashes are never included.
+func (s *Service) List(ctx context.Context) ([]*APIKey, error) {
+	return s.store.List(ctx)
+}
+
+// Rotate issues a new secret for the key. The old secret keeps working for grace.
+func (s *Service) Rotate(ctx context.Context, id string, grace time.Duration) (*APIKey, string, error) {
+	key, err := s.store.Get(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	if !key.Usable(s.now()) {
+		return nil, "", ErrInvalidKey
+	}
+
+	secret, err := newSecret(key.ID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := s.now().UTC()
+	validUntil := now.Add(grace)
+	if key.PreviousHash != "" {
+		// Only one previous secret is honoured at a time
+		if err := s.store.RemoveHash(ctx, key.PreviousHash); err != nil {
+			return nil, "", err
+		}
+	}
+	key.PreviousHash = key.Hash
+	key.PreviousValidUntil = &validUntil
+	key.Hash = HashSecret(secret)
+	key.RotatedAt = &now
+
+	if err := s.store.Save(ctx, key); err != nil {
+		return nil, "", err
+	}
+	if err := s.store.IndexHash(ctx, key.Hash, key.ID, 0); err != nil {
+		return nil, "", err
+	}
+	if grace > 0 {
+		if err := s.store.IndexHash(ctx, key.PreviousHash, key.ID, grace); err != nil {
+			return nil, "", err
+		}
+	} else if err := s.store.RemoveHash(ctx, key.PreviousHash); err != nil {
+		return nil, "", err
+	}
+
+	s.forget(key.ID)
+	return key, secret, nil
+}
+
+// Revoke disables the key immediately on this replica and within the cache
+// TTL everywhere else
+func (s *Service) Revoke(ctx context.Context, id string) error {
+	key, err := s.store.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if key.RevokedAt != nil {
+		return nil
+	}
+
+	now := s.now().UTC()
+	key.RevokedAt = &now
+	if err := s.store.Save(ctx, key); err != nil {
+		return err
+	}
+	for _, hash := range []string{key.Hash, key.PreviousHash} {
+		if hash == "" {
+			continue
+		}
+		if err := s.store.RemoveHash(ctx, hash); err != nil {
+			return err
+		}
+	}
+
+	s.forget(key.ID)
+	return nil
+}
+
+// Authenticate resolves a secret to its key and records the use
+func (s *Service) Authenticate(ctx context.Context, secret string) (*APIKey, error) {
+	if !strings.HasPrefix(secret, secretPrefix) {
+		return nil, ErrInvalidKey
+	}
+	hash := HashSecret(secret)
+	now := s.now()
+
+	s.mu.Lock()
+	cached, ok := s.cache[hash]
+	s.mu.Unlock()
+
+	key := cached.key
+	if !ok || now.After(cached.expiresAt) {
+		var err error
+		key, err = s.store.FindByHash(ctx, hash)
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrInvalidKey
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		s.mu.Lock()
+		s.cache[hash] = cachedKey{key: key, expiresAt: now.Add(s.cacheTTL)}
+		s.mu.Unlock()
+	}
+
+	if !key.Usable(now) {
+		return nil, ErrInvalidKey
+	}
+	if hash == key.PreviousHash && (key.PreviousValidUntil == nil || now.After(*key.PreviousValidUntil)) {
+		return nil, ErrInvalidKey
+	}
+
+	s.touch(ctx, key.ID, now)
+	return key, nil
+}
+
+// touch records last use at most once per minute per key
+func (s *Service) touch(ctx context.Context, id string, now time.Time) {
+	s.mu.Lock()
+	last := s.lastTouch[id]
+	if now.Sub(last) < time.Minute {
+		s.mu.Unlock()
+		return
+	}
+	s.lastTouch[id] = now
+	s.mu.Unlock()
+
+	_ = s.store.TouchLastUsed(ctx, id, now.UTC())
+}
+
+// forget drops every cached lookup of a key
+func (s *Service) forget(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for hash, cached := range s.cache {
+		if cached.key.ID == id {
+			delete(s.cache, hash)
+		}
+	}
+}
+
+// PurgeCache drops the cached lookups of the keys whose ID matches, so they
+// are read again from the store on their next use. It returns the number of
+// lookups dropped.
+func (s *Service) PurgeCache(match func(id string) bool) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	purged := 0
+	for hash, cached := range s.cache {
+		if match(cached.key.ID) {
+			delete(s.cache, hash)
+			purged++
+		}
+	}
+	return purged
+}
+
+// HashSecret returns the stored form of a secret. Secrets carry 256 bits of
+// entropy, so a plain SHA-256 is sufficient.
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func newSecret(id string) (string, error) {
+	random, err := randomHex(32)
+	if err != nil {
+		return "", err
+	}
+	return secretPrefix + id + "_" + random, nil
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,296 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryStore keeps keys in memory, expiring hash index entries on the
+// clock of the service under test
+type memoryStore struct {
+	now func() time.Time
+
+	mu      sync.Mutex
+	keys    map[string]storedKey
+	hashes  map[string]indexEntry
+	lookups int
+}
+
+type indexEntry struct {
+	id        string
+	expiresAt time.Time
+}
+
+func newMemoryStore(now func() time.Time) *memoryStore {
+	return &memoryStore{now: now, keys: make(map[string]storedKey), hashes: make(map[string]indexEntry)}
+}
+
+func (m *memoryStore) Save(ctx context.Context, key *APIKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keys[key.ID] = storedKey{APIKey: *key, Hash: key.Hash, PreviousHash: key.PreviousHash}
+	return nil
+}
+
+func (m *memoryStore) Get(ctx context.Context, id string) (*APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.get(id)
+}
+
+func (m *memoryStore) get(id string) (*APIKey, error) {
+	stored, ok := m.keys[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	key := stored.APIKey
+	key.Hash, key.PreviousHash = stored.Hash, stored.PreviousHash
+	return &key, nil
+}
+
+func (m *memoryStore) FindByHash(ctx context.Context, hash string) (*APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lookups++
+	entry, ok := m.hashes[hash]
+	if !ok || (!entry.expiresAt.IsZero() && !m.now().Before(entry.expiresAt)) {
+		return nil, ErrNotFound
+	}
+	return m.get(entry.id)
+}
+
+func (m *memoryStore) List(ctx context.Context) ([]*APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var keys []*APIKey
+	for id := range m.keys {
+		key, _ := m.get(id)
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (m *memoryStore) IndexHash(ctx context.Context, hash, id string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry := indexEntry{id: id}
+	if ttl > 0 {
+		entry.expiresAt = m.now().Add(ttl)
+	}
+	m.hashes[hash] = entry
+	return nil
+}
+
+func (m *memoryStore) RemoveHash(ctx context.Context, hash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.hashes, hash)
+	return nil
+}
+
+func (m *memoryStore) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if stored, ok := m.keys[id]; ok {
+		stored.LastUsedAt = &at
+		m.keys[id] = stored
+	}
+	return nil
+}
+
+// keyFixture is a service over a memory store, on a clock the test moves
+type keyFixture struct {
+	svc   *Service
+	store *memoryStore
+	now   time.Time
+}
+
+const testCacheTTL = 30 * time.Second
+
+func newKeyFixture(t *testing.T) *keyFixture {
+	t.Helper()
+	f := &keyFixture{now: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+	clock := func() time.Time { return f.now }
+	f.store = newMemoryStore(clock)
+	f.svc = NewService(f.store, testCacheTTL)
+	f.svc.now = clock
+	return f
+}
+
+// create issues a key with the given scopes
+func (f *keyFixture) create(t *testing.T, req CreateRequest) (*APIKey, string) {
+	t.Helper()
+	if req.Name == "" {
+		req.Name = "zapier"
+	}
+	if len(req.Scopes) == 0 {
+		req.Scopes = []string{ScopeReadForms}
+	}
+	key, secret, err := f.svc.Create(context.Background(), req, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, secret
+}
+
+func (f *keyFixture) authenticate(secret string) error {
+	_, err := f.svc.Authenticate(context.Background(), secret)
+	return err
+}
+
+func TestAuthenticateByHash(t *testing.T) {
+	f := newKeyFixture(t)
+	key, secret := f.create(t, CreateRequest{})
+
+	if !strings.HasPrefix(secret, key.Prefix+"_") {
+		t.Errorf("secret %q does not start with the key prefix %q", secret, key.Prefix)
+	}
+	stored, _ := f.store.Get(context.Background(), key.ID)
+	if stored.Hash != HashSecret(secret) || strings.Contains(stored.Hash, secret) {
+		t.Errorf("stored hash = %q, want the SHA-256 of the secret", stored.Hash)
+	}
+
+	got, err := f.svc.Authenticate(context.Background(), secret)
+	if err != nil || got.ID != key.ID {
+		t.Fatalf("Authenticate = %v, %v; want key %s", got, err, key.ID)
+	}
+	if stored, _ := f.store.Get(context.Background(), key.ID); stored.LastUsedAt == nil {
+		t.Error("last use was not recorded")
+	}
+
+	tests := []struct {
+		name   string
+		secret string
+	}{
+		{"unknown secret", secretPrefix + key.ID + "_" + strings.Repeat("0", 64)},
+		{"secret without the prefix", strings.TrimPrefix(secret, secretPrefix)},
+		{"truncated secret", secret[:len(secret)-1]},
+		{"empty secret", ""},
+	}
+	for _, tt := range tests {
+		if err := f.authenticate(tt.secret); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("%s: err = %v, want ErrInvalidKey", tt.name, err)
+		}
+	}
+}
+
+func TestRotationGracePeriod(t *testing.T) {
+	f := newKeyFixture(t)
+	key, oldSecret := f.create(t, CreateRequest{})
+
+	rotated, newSecret, err := f.svc.Rotate(context.Background(), key.ID, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newSecret == oldSecret || rotated.PreviousHash != HashSecret(oldSecret) {
+		t.Fatalf("rotation kept the old secret as current")
+	}
+
+	f.now = f.now.Add(time.Hour - 10*time.Second)
+	if err := f.authenticate(oldSecret); err != nil {
+		t.Errorf("old secret within the grace period: %v", err)
+	}
+	if err := f.authenticate(newSecret); err != nil {
+		t.Errorf("new secret: %v", err)
+	}
+
+	// The cached lookup of the old secret must not outlive the grace period
+	f.now = f.now.Add(20 * time.Second)
+	if err := f.authenticate(oldSecret); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("old secret after the grace period: err = %v, want ErrInvalidKey", err)
+	}
+	f.now = f.now.Add(testCacheTTL)
+	if err := f.authenticate(oldSecret); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("old secret once its index entry expired: err = %v, want ErrInvalidKey", err)
+	}
+	if err := f.authenticate(newSecret); err != nil {
+		t.Errorf("new secret after the grace period: %v", err)
+	}
+}
+
+func TestRotationWithoutGrace(t *testing.T) {
+	f := newKeyFixture(t)
+	key, oldSecret := f.create(t, CreateRequest{})
+	if err := f.authenticate(oldSecret); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := f.svc.Rotate(context.Background(), key.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.authenticate(oldSecret); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("old secret rotated without grace: err = %v, want ErrInvalidKey", err)
+	}
+}
+
+func TestRevokeDropsCachedLookups(t *testing.T) {
+	f := newKeyFixture(t)
+	key, secret := f.create(t, CreateRequest{})
+
+	for i := 0; i < 2; i++ {
+		if err := f.authenticate(secret); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if f.store.lookups != 1 {
+		t.Fatalf("store looked up %d times, want the second use served from the cache", f.store.lookups)
+	}
+
+	if err := f.svc.Revoke(context.Background(), key.ID); err != nil {
+		t.Fatal(err)
+	}
+	f.now = f.now.Add(time.Second)
+	if err := f.authenticate(secret); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("revoked key within the cache TTL: err = %v, want ErrInvalidKey", err)
+	}
+	if _, _, err := f.svc.Rotate(context.Background(), key.ID, time.Hour); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("rotating a revoked key: err = %v, want ErrInvalidKey", err)
+	}
+}
+
+func TestExpiredKeysAreRejected(t *testing.T) {
+	f := newKeyFixture(t)
+	expiresAt := f.now.Add(time.Hour)
+	_, secret := f.create(t, CreateRequest{ExpiresAt: &expiresAt})
+
+	if err := f.authenticate(secret); err != nil {
+		t.Fatalf("key before it expires: %v", err)
+	}
+	f.now = expiresAt
+	if err := f.authenticate(secret); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("expired key: err = %v, want ErrInvalidKey", err)
+	}
+
+	past := f.now.Add(-time.Minute)
+	if _, _, err := f.svc.Create(context.Background(), CreateRequest{Name: "late", Scopes: []string{ScopeReadForms}, ExpiresAt: &past}, ""); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("key expiring in the past: err = %v, want ErrInvalidRequest", err)
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	tests := []struct {
+		scopes []string
+		scope  string
+		want   bool
+	}{
+		{[]string{ScopeReadForms}, ScopeReadForms, true},
+		{[]string{ScopeReadForms}, ScopeWriteForms, false},
+		{[]string{ScopeAdmin}, ScopeWriteResponses, true},
+		{nil, ScopeReadForms, false},
+	}
+	for _, tt := range tests {
+		key := &APIKey{Scopes: tt.scopes}
+		if got := key.HasScope(tt.scope); got != tt.want {
+			t.Errorf("%v HasScope(%s) = %v, want %v", tt.scopes, tt.scope, got, tt.want)
+		}
+	}
+
+	f := newKeyFixture(t)
+	if _, _, err := f.svc.Create(context.Background(), CreateRequest{Name: "bad", Scopes: []string{"delete:everything"}}, ""); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("unknown scope: err = %v, want ErrInvalidRequest", err)
+	}
+}
@@ -0,0 +1,138 @@
+package apikey
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	keyPrefix      = "apikey:key:"
+	hashPrefix     = "apikey:hash:"
+	lastUsedPrefix = "apikey:last_used:"
+	idsKey         = "apikey:ids"
+)
+
+// RedisStore keeps API keys in Redis
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore creates a store backed by the Redis instance at redisURL
+func NewRedisStore(redisURL string) (*RedisStore, error) {
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Redis URL: %w", err)
+	}
+	return &RedisStore{client: redis.NewClient(opts)}, nil
+}
+
+// Save writes the key record
+func (s *RedisStore) Save(ctx context.Context, key *APIKey) error {
+	data, err := json.Marshal(storedKey{APIKey: *key, Hash: key.Hash, PreviousHash: key.PreviousHash})
+	if err != nil {
+		return fmt.Errorf("failed to encode api key: %w", err)
+	}
+
+	pipe := s.client.TxPipeline()
+	pipe.Set(ctx, keyPrefix+key.ID, data, 0)
+	pipe.SAdd(ctx, idsKey, key.ID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store api key: %w", err)
+	}
+	return nil
+}
+
+// Get loads a key record together with its last-used time
+func (s *RedisStore) Get(ctx context.Context, id string) (*APIKey, error) {
+	data, err := s.client.Get(ctx, keyPrefix+id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load api key: %w", err)
+	}
+
+	var stored storedKey
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("invalid api key record %s: %w", id, err)
+	}
+	key := stored.APIKey
+	key.Hash = stored.Hash
+	key.PreviousHash = stored.PreviousHash
+
+	if lastUsed, err := s.client.Get(ctx, lastUsedPrefix+id).Time(); err == nil {
+		key.LastUsedAt = &lastUsed
+	}
+
+	return &key, nil
+}
+
+// FindByHash resolves a secret hash to its key
+func (s *RedisStore) FindByHash(ctx context.Context, hash string) (*APIKey, error) {
+	id, err := s.client.Get(ctx, hashPrefix+hash).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up api key: %w", err)
+	}
+	return s.Get(ctx, id)
+}
+
+// List returns every key ordered by creation time
+func (s *RedisStore) List(ctx context.Context) ([]*APIKey, error) {
+	ids, err := s.client.SMembers(ctx, idsKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+
+	keys := make([]*APIKey, 0, len(ids))
+	for _, id := range ids {
+		key, err := s.Get(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+	return keys, nil
+}
+
+// IndexHash maps a secret hash to a key id, expiring after ttl when ttl > 0
+func (s *RedisStore) IndexHash(ctx context.Context, hash, id string, ttl time.Duration) error {
+	if err := s.client.Set(ctx, hashPrefix+hash, id, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to index api key: %w", err)
+	}
+	return nil
+}
+
+// RemoveHash drops a secret hash from the index
+func (s *RedisStore) RemoveHash(ctx context.Context, hash string) error {
+	if err := s.client.Del(ctx, hashPrefix+hash).Err(); err != nil {
+		return fmt.Errorf("failed to remove api key index: %w", err)
+	}
+	return nil
+}
+
+// TouchLastUsed records when a key was last used. It is kept outside the key
+// record so it never races with rotation or revocation.
+func (s *RedisStore) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	return s.client.Set(ctx, lastUsedPrefix+id, at, 0).Err()
+}
+
+// Close closes the Redis connection
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
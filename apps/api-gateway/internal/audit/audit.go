@@ -0,0 +1,224 @@
+// Package audit records the admin actions taken through the gateway. Each
+// action is published as an audit.<action> event to the audit topic of the
+// event bus, which appends it to the hash-chained audit log. Recording never
+// blocks or fails the request: events wait in a bounded buffer and are
+// published in the background, and events recorded while the buffer is full
+// are dropped and counted.
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/config"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/metrics"
+)
+
+// eventSource is the source of the audit events
+const eventSource = "api-gateway"
+
+// Delivery outcomes counted in audit_events_total
+const (
+	outcomeDelivered = "delivered"
+	outcomeFailed    = "failed"
+	outcomeDropped   = "dropped"
+)
+
+// Event is an action to record
+type Event struct {
+	// Action names what was done, such as apikey.created
+	Action       string
+	Actor        string
+	ResourceType string
+	ResourceID   string
+	// Before and After summarize the resource around the action; either may
+	// be nil
+	Before        interface{}
+	After         interface{}
+	IP            string
+	CorrelationID string
+	OccurredAt    time.Time
+}
+
+// Recorder publishes recorded events to the event bus in the background. A
+// nil Recorder records nothing.
+type Recorder struct {
+	url      string
+	topic    string
+	client   *http.Client
+	attempts int
+	backoff  time.Duration
+	metrics  *metrics.Collector
+	logger   logger.Logger
+
+	events chan Event
+	// ctx aborts deliveries once Stop gives up waiting for them
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewRecorder creates a recorder publishing to the event bus of cfg. Start
+// starts publishing.
+func NewRecorder(cfg config.AuditConfig, metrics *metrics.Collector, logger logger.Logger) *Recorder {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Recorder{
+		url:      strings.TrimSuffix(cfg.EventBusURL, "/") + "/events",
+		topic:    cfg.Topic,
+		client:   &http.Client{Timeout: cfg.Timeout},
+		attempts: cfg.RetryAttempts + 1,
+		backoff:  cfg.RetryBackoff,
+		metrics:  metrics,
+		logger:   logger,
+		events:   make(chan Event, cfg.BufferSize),
+		ctx:      ctx,
+		cancel:   cancel,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Record queues event for publishing without waiting. The event is dropped
+// when the buffer is full.
+func (r *Recorder) Record(event Event) {
+	if r == nil {
+		return
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now().UTC()
+	}
+
+	select {
+	case r.events <- event:
+		r.metrics.AuditBufferDepth.Set(float64(len(r.events)))
+	default:
+		r.metrics.AuditEvents.WithLabelValues(outcomeDropped).Inc()
+		r.logger.Warnf("Audit buffer full, dropped %s by %s on %s %s", event.Action, event.Actor, event.ResourceType, event.ResourceID)
+	}
+}
+
+// Start starts publishing the recorded events
+func (r *Recorder) Start() {
+	go r.run()
+}
+
+// Stop publishes the events still buffered and stops. Deliveries still
+// running when ctx is done are abandoned.
+func (r *Recorder) Stop(ctx context.Context) {
+	r.stopOnce.Do(func() { close(r.stop) })
+	select {
+	case <-r.done:
+	case <-ctx.Done():
+		r.cancel()
+		<-r.done
+	}
+	r.cancel()
+}
+
+func (r *Recorder) run() {
+	defer close(r.done)
+	for {
+		select {
+		case event := <-r.events:
+			r.publish(event)
+		case <-r.stop:
+			for {
+				select {
+				case event := <-r.events:
+					r.publish(event)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// publish delivers event, retrying failed attempts with a growing backoff
+func (r *Recorder) publish(event Event) {
+	r.metrics.AuditBufferDepth.Set(float64(len(r.events)))
+
+	body, err := r.encode(event)
+	if err != nil {
+		r.metrics.AuditEvents.WithLabelValues(outcomeFailed).Inc()
+		r.logger.Errorf("Failed to encode audit event %s: %v", event.Action, err)
+		return
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = r.deliver(body)
+		if err == nil {
+			r.metrics.AuditEvents.WithLabelValues(outcomeDelivered).Inc()
+			return
+		}
+		if attempt >= r.attempts || r.ctx.Err() != nil {
+			break
+		}
+		select {
+		case <-time.After(time.Duration(attempt) * r.backoff):
+		case <-r.ctx.Done():
+		}
+	}
+
+	r.metrics.AuditEvents.WithLabelValues(outcomeFailed).Inc()
+	r.logger.Errorf("Failed to publish audit event %s by %s on %s %s: %v", event.Action, event.Actor, event.ResourceType, event.ResourceID, err)
+}
+
+// encode builds the publish request of event. The event ID is generated
+// once, so a retried delivery the event bus already accepted is logged once.
+func (r *Recorder) encode(event Event) ([]byte, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate audit event id: %w", err)
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"id":         "audit_" + hex.EncodeToString(id),
+		"event_type": "audit." + event.Action,
+		"source":     eventSource,
+		"subject":    event.Actor,
+		"topic":      r.topic,
+		"key":        event.Actor,
+		"data": map[string]interface{}{
+			"actor":          event.Actor,
+			"resource_type":  event.ResourceType,
+			"resource_id":    event.ResourceID,
+			"before":         event.Before,
+			"after":          event.After,
+			"ip":             event.IP,
+			"correlation_id": event.CorrelationID,
+			"occurred_at":    event.OccurredAt,
+		},
+	})
+}
+
+func (r *Recorder) deliver(body []byte) error {
+	req, err := http.NewRequestWithContext(r.ctx, http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("event bus answered %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
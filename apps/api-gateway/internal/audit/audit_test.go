@@ -0,0 +1,132 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/config"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/metrics"
+)
+
+// eventBus records the events published to it, failing the first failures
+// requests
+type eventBus struct {
+	mu       sync.Mutex
+	failures int
+	requests int
+	events   []map[string]interface{}
+}
+
+func (b *eventBus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.requests++
+	if b.requests <= b.failures {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	var event map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	b.events = append(b.events, event)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func newTestRecorder(t *testing.T, bus *eventBus, bufferSize int) (*Recorder, *metrics.Collector) {
+	t.Helper()
+	server := httptest.NewServer(bus)
+	t.Cleanup(server.Close)
+
+	collector := metrics.NewCollector(metrics.Config{})
+	log := logger.New(logger.LogConfig{Level: "fatal", Format: "json", Output: "stderr"})
+	recorder := NewRecorder(config.AuditConfig{
+		EventBusURL:   server.URL,
+		Topic:         "audit-log",
+		BufferSize:    bufferSize,
+		Timeout:       time.Second,
+		RetryAttempts: 2,
+		RetryBackoff:  time.Millisecond,
+	}, collector, log)
+	return recorder, collector
+}
+
+func TestRecorderPublishesWithRetries(t *testing.T) {
+	bus := &eventBus{failures: 2}
+	recorder, collector := newTestRecorder(t, bus, 10)
+	recorder.Start()
+
+	recorder.Record(Event{
+		Action: "apikey.revoked", Actor: "admin-1", ResourceType: "api_key", ResourceID: "key-1",
+		IP: "203.0.113.7", CorrelationID: "req-1",
+	})
+	recorder.Stop(context.Background())
+
+	if len(bus.events) != 1 || bus.requests != 3 {
+		t.Fatalf("published %d events in %d requests, want 1 in 3", len(bus.events), bus.requests)
+	}
+	event := bus.events[0]
+	if event["event_type"] != "audit.apikey.revoked" || event["topic"] != "audit-log" {
+		t.Errorf("event = %v", event)
+	}
+	data := event["data"].(map[string]interface{})
+	if data["actor"] != "admin-1" || data["resource_id"] != "key-1" || data["correlation_id"] != "req-1" {
+		t.Errorf("data = %v", data)
+	}
+	if got := testutil.ToFloat64(collector.AuditEvents.WithLabelValues(outcomeDelivered)); got != 1 {
+		t.Errorf("delivered = %v, want 1", got)
+	}
+}
+
+func TestRecorderDropsWhenBufferIsFull(t *testing.T) {
+	bus := &eventBus{}
+	recorder, collector := newTestRecorder(t, bus, 2)
+
+	// Not started, so nothing leaves the buffer
+	for i := 0; i < 5; i++ {
+		recorder.Record(Event{Action: "maintenance.updated", Actor: "admin-1"})
+	}
+	if got := testutil.ToFloat64(collector.AuditEvents.WithLabelValues(outcomeDropped)); got != 3 {
+		t.Errorf("dropped = %v, want 3", got)
+	}
+	if got := testutil.ToFloat64(collector.AuditBufferDepth); got != 2 {
+		t.Errorf("buffer depth = %v, want 2", got)
+	}
+
+	recorder.Start()
+	recorder.Stop(context.Background())
+	if len(bus.events) != 2 {
+		t.Errorf("published %d buffered events on stop, want 2", len(bus.events))
+	}
+}
+
+func TestRecorderCountsFailures(t *testing.T) {
+	bus := &eventBus{failures: 10}
+	recorder, collector := newTestRecorder(t, bus, 10)
+	recorder.Start()
+
+	recorder.Record(Event{Action: "apikey.created", Actor: "admin-1"})
+	recorder.Stop(context.Background())
+
+	if bus.requests != 3 {
+		t.Errorf("attempted %d deliveries, want 3", bus.requests)
+	}
+	if got := testutil.ToFloat64(collector.AuditEvents.WithLabelValues(outcomeFailed)); got != 1 {
+		t.Errorf("failed = %v, want 1", got)
+	}
+}
+
+func TestNilRecorderRecordsNothing(t *testing.T) {
+	var recorder *Recorder
+	recorder.Record(Event{Action: "apikey.created"})
+}
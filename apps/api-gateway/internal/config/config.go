@@ -3,12 +3,16 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/Mir00r/X-Form-Backend/shared/flags"
 	"github.com/spf13/viper"
 )
 
@@ -51,6 +55,39 @@ type Config struct {
 
 	// Rate limiting configuration
 	RateLimit RateLimitConfig `mapstructure:"rate_limit" validate:"required"`
+
+	// Maintenance mode configuration
+	Maintenance MaintenanceConfig `mapstructure:"maintenance"`
+
+	// Health overrides of the service registry set by admins
+	Registry RegistryConfig `mapstructure:"registry"`
+
+	// Per-route timeout, retry, breaker, rate limit, body size and cache policies
+	Policies PoliciesConfig `mapstructure:"policies"`
+
+	// Data subject erasure and export requests
+	Privacy PrivacyConfig `mapstructure:"privacy"`
+
+	// Audit events of admin actions
+	Audit AuditConfig `mapstructure:"audit"`
+
+	// Admin operations on forms, relayed to the form service
+	FormAdmin FormAdminConfig `mapstructure:"form_admin"`
+
+	// Admin clears of the gateway and service caches
+	CacheClear CacheClearConfig `mapstructure:"cache_clear"`
+
+	// Rejection of low-priority traffic while the gateway is overloaded
+	LoadShedding LoadSheddingConfig `mapstructure:"load_shedding"`
+
+	// Feature flags shared with the services
+	FeatureFlags FeatureFlagsConfig `mapstructure:"feature_flags"`
+
+	// Reporting of panics and upstream failures to the error tracker
+	ErrorReporting ErrorReportingConfig `mapstructure:"error_reporting"`
+
+	// Faults injected into proxied requests for resilience testing
+	FaultInjection FaultInjectionConfig `mapstructure:"fault_injection"`
 }
 
 // ServerConfig holds HTTP server configuration
@@ -63,6 +100,18 @@ type ServerConfig struct {
 	Timeout      time.Duration `mapstructure:"timeout" validate:"required"`
 	// TLS configuration
 	TLS TLSConfig `mapstructure:"tls"`
+	// Shutdown configuration
+	Shutdown ShutdownConfig `mapstructure:"shutdown"`
+}
+
+// ShutdownConfig holds how the server drains on SIGTERM
+type ShutdownConfig struct {
+	// PreStopDelay is how long requests are still served after readiness
+	// turns to 503, while the load balancer stops routing to the gateway
+	PreStopDelay time.Duration `mapstructure:"pre_stop_delay"`
+	// DrainTimeout bounds the wait for in-flight requests once the listener
+	// is closed; requests still running then are aborted
+	DrainTimeout time.Duration `mapstructure:"drain_timeout"`
 }
 
 // TLSConfig holds TLS/SSL configuration
@@ -102,6 +151,12 @@ type SecurityConfig struct {
 
 	// Validation configuration
 	Validation ValidationConfig `mapstructure:"validation"`
+
+	// API key configuration
+	APIKeys APIKeyConfig `mapstructure:"api_keys"`
+
+	// mTLS configuration for the calls to the internal services
+	MTLS MTLSConfig `mapstructure:"mtls"`
 }
 
 // JWTConfig holds JWT-specific configuration
@@ -116,6 +171,24 @@ type JWTConfig struct {
 	Audience       string        `mapstructure:"audience" validate:"required"`
 }
 
+// MTLSConfig holds the client certificate the gateway presents to the
+// internal services and how their certificates are verified
+type MTLSConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Enforce refuses to proxy to a service over plain HTTP
+	Enforce  bool   `mapstructure:"enforce"`
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+	// CAFile is the bundle of the internal CA the services' certificates chain to
+	CAFile string `mapstructure:"ca_file"`
+	// ReloadInterval is how often the files are checked for a rotation
+	ReloadInterval time.Duration `mapstructure:"reload_interval"`
+	// Identities maps a service to the SPIFFE ID its certificate must carry,
+	// e.g. spiffe://xform.internal/ns/default/sa/form-service. Services
+	// without one are verified by host name.
+	Identities map[string]string `mapstructure:"identities"`
+}
+
 // WhitelistConfig holds IP whitelist configuration
 type WhitelistConfig struct {
 	Enabled     bool     `mapstructure:"enabled"`
@@ -125,6 +198,15 @@ type WhitelistConfig struct {
 	ProxyHeader string   `mapstructure:"proxy_header"`
 }
 
+// APIKeyConfig holds API key storage and validation configuration
+type APIKeyConfig struct {
+	RedisURL string `mapstructure:"redis_url"`
+	// How long a validated key is cached locally; bounds revocation delay
+	CacheTTL time.Duration `mapstructure:"cache_ttl"`
+	// How long the previous secret keeps working after a rotation
+	RotationGracePeriod time.Duration `mapstructure:"rotation_grace_period"`
+}
+
 // RateLimitConfig holds rate limiting configuration
 type RateLimitConfig struct {
 	Enabled  bool          `mapstructure:"enabled"`
@@ -134,6 +216,11 @@ type RateLimitConfig struct {
 	RedisURL string        `mapstructure:"redis_url"`
 	// Per-endpoint rate limits
 	Endpoints map[string]EndpointRateLimit `mapstructure:"endpoints"`
+	// Tiers are named limits route policies refer to
+	Tiers map[string]EndpointRateLimit `mapstructure:"tiers"`
+	// Overrides replace the global limit of the clients they name, as
+	// apikey:<id>, user:<id> or org:<id>
+	Overrides map[string]EndpointRateLimit `mapstructure:"overrides"`
 }
 
 // EndpointRateLimit holds endpoint-specific rate limiting
@@ -152,6 +239,198 @@ type SecurityHeadersConfig struct {
 	ReferrerPolicy        string `mapstructure:"referrer_policy"`
 }
 
+// MaintenanceConfig holds maintenance mode configuration
+type MaintenanceConfig struct {
+	RedisURL        string        `mapstructure:"redis_url"`
+	Key             string        `mapstructure:"key"`
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+	// Path prefixes that stay reachable during maintenance
+	AllowedPaths []string `mapstructure:"allowed_paths"`
+	// Roles that bypass maintenance entirely
+	BypassRoles []string `mapstructure:"bypass_roles"`
+}
+
+// RegistryConfig holds the settings of the health overrides admins pin on
+// the instances of the service registry. Overrides are kept in the Redis
+// hash Key, so they outlive restarts, and every replica reloads them every
+// RefreshInterval.
+type RegistryConfig struct {
+	RedisURL        string        `mapstructure:"redis_url"`
+	Key             string        `mapstructure:"key"`
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+}
+
+// FaultInjectionConfig holds the injection of faults into the requests the
+// gateway proxies, to exercise its retries, breakers and timeouts outside
+// production. Admins configure the rules at runtime, presenting Token in the
+// X-Fault-Injection-Token header.
+type FaultInjectionConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Token   string `mapstructure:"token"`
+	// MaxDuration bounds how long a rule lasts before it expires
+	MaxDuration time.Duration `mapstructure:"max_duration"`
+}
+
+// PrivacyConfig holds the settings of data subject erasure and export
+// requests. The requests are published to the event bus, which posts the
+// completion of each participant back to the gateway.
+type PrivacyConfig struct {
+	RedisURL    string `mapstructure:"redis_url"`
+	EventBusURL string `mapstructure:"event_bus_url"`
+	// CallbackToken authenticates the completions the event bus posts back;
+	// completions are refused while it is empty
+	CallbackToken string        `mapstructure:"callback_token"`
+	Timeout       time.Duration `mapstructure:"timeout"`
+	// The participants whose completion a request waits for
+	ErasureParticipants []string `mapstructure:"erasure_participants"`
+	ExportParticipants  []string `mapstructure:"export_participants"`
+}
+
+// AuditConfig holds the settings of the audit events recorded for admin
+// actions. Events are buffered and published to the event bus in the
+// background, which keeps the audit log and serves its queries.
+type AuditConfig struct {
+	Enabled     bool   `mapstructure:"enabled"`
+	EventBusURL string `mapstructure:"event_bus_url"`
+	Topic       string `mapstructure:"topic"`
+	// BufferSize bounds the events waiting to be published; events recorded
+	// while it is full are dropped
+	BufferSize    int           `mapstructure:"buffer_size"`
+	Timeout       time.Duration `mapstructure:"timeout"`
+	RetryAttempts int           `mapstructure:"retry_attempts"`
+	RetryBackoff  time.Duration `mapstructure:"retry_backoff"`
+}
+
+// FormAdminConfig holds the settings of the admin operations on forms, such
+// as cleanups of abandoned forms, which are relayed to the internal API of
+// the form service
+type FormAdminConfig struct {
+	FormServiceURL string `mapstructure:"form_service_url"`
+	// ServiceToken authenticates the gateway to the internal API; the form
+	// service refuses its guarded routes without it
+	ServiceToken string        `mapstructure:"service_token"`
+	Timeout      time.Duration `mapstructure:"timeout"`
+}
+
+// CacheClearConfig holds the settings of the admin clears of caches. A
+// replica clears caches at most once per MinInterval, so the endpoint cannot
+// keep the caches empty and the services under their full load.
+type CacheClearConfig struct {
+	MinInterval time.Duration `mapstructure:"min_interval"`
+}
+
+// FeatureFlagsConfig holds the settings of feature flags. Flags are defined
+// in Flags, a JSON array, and changed at runtime in the Redis hash RedisKey
+// every service reads, each refreshing its snapshot every RefreshInterval.
+type FeatureFlagsConfig struct {
+	RedisURL        string        `mapstructure:"redis_url"`
+	RedisKey        string        `mapstructure:"redis_key"`
+	Flags           string        `mapstructure:"flags"`
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+}
+
+// ErrorReportingConfig selects the error tracker panics and upstream
+// failures are reported to: noop or sentry, which needs DSN. Each error is
+// reported Burst times per Interval, plus SampleRate of the rest.
+type ErrorReportingConfig struct {
+	Provider   string        `mapstructure:"provider"`
+	DSN        string        `mapstructure:"dsn"`
+	Burst      int           `mapstructure:"burst"`
+	Interval   time.Duration `mapstructure:"interval"`
+	SampleRate float64       `mapstructure:"sample_rate"`
+}
+
+// LoadSheddingConfig holds the limits past which the gateway rejects its
+// lowest-priority traffic with 503 instead of queueing it behind a slow
+// service. Anonymous requests are shed from AnonymousInFlight requests in
+// flight, requests to non-critical route classes from NonCriticalInFlight,
+// and every request from MaxInFlight; the exempt paths are always admitted.
+// A threshold of 0 sheds those requests at MaxInFlight only.
+type LoadSheddingConfig struct {
+	Enabled             bool `mapstructure:"enabled"`
+	MaxInFlight         int  `mapstructure:"max_in_flight"`
+	AnonymousInFlight   int  `mapstructure:"anonymous_in_flight"`
+	NonCriticalInFlight int  `mapstructure:"non_critical_in_flight"`
+	// LatencyWindow is the sliding window the p99 latency of a route class
+	// is measured over
+	LatencyWindow time.Duration `mapstructure:"latency_window"`
+	// RetryAfter is sent to the clients of shed requests
+	RetryAfter time.Duration `mapstructure:"retry_after"`
+	// Path prefixes never shed, such as health checks and admin endpoints
+	ExemptPaths []string `mapstructure:"exempt_paths"`
+	// Classes group routes by path prefix; requests matching none are in
+	// the non-critical default class
+	Classes []RouteClassConfig `mapstructure:"classes"`
+}
+
+// RouteClassConfig holds a class of routes shed together
+type RouteClassConfig struct {
+	Name     string   `mapstructure:"name"`
+	Prefixes []string `mapstructure:"prefixes"`
+	// Critical classes are only shed past MaxInFlight
+	Critical bool `mapstructure:"critical"`
+	// LatencyTarget is the p99 latency the class should stay under. Past it
+	// anonymous requests to the class are shed, and past twice it the
+	// requests to a non-critical class too; 0 leaves latency unchecked.
+	LatencyTarget time.Duration `mapstructure:"latency_target"`
+}
+
+// PoliciesConfig maps gateway path patterns to the policies applied to their
+// requests. Patterns have :param and *wildcard segments, as in the routes
+// package; the most specific pattern matching a request applies, and
+// requests matching none get the default policy.
+type PoliciesConfig struct {
+	Default RoutePolicyConfig            `mapstructure:"default"`
+	Routes  map[string]RoutePolicyConfig `mapstructure:"routes"`
+}
+
+// RoutePolicyConfig holds the policy of a route. Settings left out of a
+// route policy are inherited from the default policy.
+type RoutePolicyConfig struct {
+	Timeout time.Duration `mapstructure:"timeout"`
+	// RetryAttempts is how often a failed idempotent request is retried
+	RetryAttempts *int                `mapstructure:"retry_attempts"`
+	Breaker       BreakerPolicyConfig `mapstructure:"breaker"`
+	// RateLimitTier names one of security.rate_limit.tiers
+	RateLimitTier string `mapstructure:"rate_limit_tier"`
+	// MaxBodyBytes caps the request body, 0 for no limit
+	MaxBodyBytes *int64 `mapstructure:"max_body_bytes"`
+	// CacheTTL is the max-age of cacheable responses not setting their own
+	CacheTTL *time.Duration `mapstructure:"cache_ttl"`
+	// Rewrite hides the internal URLs of services from the responses
+	Rewrite *RewritePolicyConfig `mapstructure:"rewrite"`
+}
+
+// RewritePolicyConfig rewrites the responses of a route, which are proxied
+// untouched unless enabled
+type RewritePolicyConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// PublicURL replaces the URL of the service, and InternalURLs, in the
+	// Location, Content-Location and Link headers
+	PublicURL    string   `mapstructure:"public_url"`
+	InternalURLs []string `mapstructure:"internal_urls"`
+	// DropHeaders are removed from the responses; a trailing * matches
+	// every header with the prefix, as in X-Internal-*
+	DropHeaders []string `mapstructure:"drop_headers"`
+	// Body replaces URL prefixes in the strings of JSON bodies
+	Body []URLRewriteConfig `mapstructure:"body"`
+	// MaxBodyBytes caps the JSON bodies rewritten; larger ones are
+	// streamed untouched
+	MaxBodyBytes int64 `mapstructure:"max_body_bytes"`
+}
+
+// URLRewriteConfig replaces the URL prefix From with To
+type URLRewriteConfig struct {
+	From string `mapstructure:"from"`
+	To   string `mapstructure:"to"`
+}
+
+// BreakerPolicyConfig holds the circuit breaker thresholds of a route
+type BreakerPolicyConfig struct {
+	FailureThreshold int           `mapstructure:"failure_threshold"`
+	RecoveryTimeout  time.Duration `mapstructure:"recovery_timeout"`
+}
+
 // AuthConfig holds authentication service configuration
 type AuthConfig struct {
 	ServiceURL    string        `mapstructure:"service_url" validate:"required,url"`
@@ -202,7 +481,35 @@ type ProxyConfig struct {
 	Timeout   time.Duration `mapstructure:"timeout" validate:"required"`
 	KeepAlive time.Duration `mapstructure:"keep_alive" validate:"required"`
 
-	// Add any other proxy configuration fields here
+	// Connection pool of each service
+	MaxIdleConns    int           `mapstructure:"max_idle_conns"`
+	MaxConnsPerHost int           `mapstructure:"max_conns_per_host"`
+	IdleConnTimeout time.Duration `mapstructure:"idle_conn_timeout"`
+
+	// Coalesce lets identical concurrent GETs share one upstream call, for
+	// responses up to CoalesceMaxBodyBytes
+	Coalesce             bool  `mapstructure:"coalesce"`
+	CoalesceMaxBodyBytes int64 `mapstructure:"coalesce_max_body_bytes"`
+
+	// Cache keeps the responses carrying an ETag of the routes with a
+	// cache_ttl, up to CacheMaxEntries responses of CacheMaxBodyBytes each,
+	// and revalidates them upstream once expired
+	Cache             bool  `mapstructure:"cache"`
+	CacheMaxEntries   int   `mapstructure:"cache_max_entries"`
+	CacheMaxBodyBytes int64 `mapstructure:"cache_max_body_bytes"`
+	// CacheInvalidation purges the cached views of forms as they change
+	CacheInvalidation CacheInvalidationConfig `mapstructure:"cache_invalidation"`
+}
+
+// CacheInvalidationConfig holds the subscription of the gateway to the form
+// invalidations the event bus publishes to Channel. Invalidations received
+// within CoalesceWindow of each other purge a form once; the cache TTL bounds
+// how stale the views missed while disconnected stay.
+type CacheInvalidationConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	RedisURL       string        `mapstructure:"redis_url"`
+	Channel        string        `mapstructure:"channel"`
+	CoalesceWindow time.Duration `mapstructure:"coalesce_window"`
 }
 
 // Load loads the configuration from file and environment variables
@@ -257,8 +564,8 @@ func Load() (*Config, error) {
 	}
 
 	// Validate configuration
-	if err := validateConfig(&config); err != nil {
-		return nil, fmt.Errorf("invalid configuration: %w", err)
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
 	}
 
 	return &config, nil
@@ -277,8 +584,14 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("server.write_timeout", 30)
 	v.SetDefault("server.idle_timeout", 60)
 	v.SetDefault("server.timeout", 30)
+	v.SetDefault("server.shutdown.pre_stop_delay", "5s")
+	v.SetDefault("server.shutdown.drain_timeout", "20s")
 
 	// Security defaults
+	// Declared so the secrets can be set from the environment, e.g.
+	// API_GATEWAY_SECURITY_JWT_SECRET
+	v.SetDefault("security.jwt.secret", "")
+	v.SetDefault("security.jwt.public_key", "")
 	v.SetDefault("security.jwt.algorithm", "HS256")
 	v.SetDefault("security.jwt.expiration_time", 3600)
 	v.SetDefault("security.jwt.refresh_time", 86400)
@@ -291,12 +604,107 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("security.rate_limit.burst", 200)
 	v.SetDefault("security.rate_limit.window", 60)
 
+	// API key defaults
+	v.SetDefault("security.api_keys.redis_url", "redis://localhost:6379/0")
+	v.SetDefault("security.api_keys.cache_ttl", "30s")
+	v.SetDefault("security.api_keys.rotation_grace_period", "24h")
+
+	// mTLS defaults
+	v.SetDefault("security.mtls.enabled", false)
+	v.SetDefault("security.mtls.enforce", false)
+	v.SetDefault("security.mtls.reload_interval", "30s")
+
+	// OpenAPI validation defaults
+	v.SetDefault("validation.openapi.enabled", false)
+	v.SetDefault("validation.openapi.route_groups", []string{"/api/v1"})
+
+	// Maintenance defaults
+	v.SetDefault("maintenance.redis_url", "redis://localhost:6379/0")
+	v.SetDefault("maintenance.key", "gateway:maintenance")
+	v.SetDefault("maintenance.refresh_interval", "5s")
+	v.SetDefault("maintenance.allowed_paths", []string{"/health", "/ready", "/metrics", "/api/v1/health", "/api/v1/metrics", "/api/v1/admin"})
+	v.SetDefault("maintenance.bypass_roles", []string{"admin", "super_admin"})
+
+	// Registry override defaults
+	v.SetDefault("registry.redis_url", "redis://localhost:6379/0")
+	v.SetDefault("registry.key", "gateway:registry:overrides")
+	v.SetDefault("registry.refresh_interval", "5s")
+
+	// Privacy defaults
+	v.SetDefault("privacy.redis_url", "redis://localhost:6379/0")
+	v.SetDefault("privacy.event_bus_url", "http://event-bus-service:8004")
+	v.SetDefault("privacy.callback_token", "")
+	v.SetDefault("privacy.timeout", "10s")
+	v.SetDefault("privacy.erasure_participants", []string{"form-service", "response-store", "collaboration-service"})
+	v.SetDefault("privacy.export_participants", []string{"form-service", "response-store"})
+
+	// Audit defaults
+	v.SetDefault("audit.enabled", false)
+	v.SetDefault("audit.event_bus_url", "http://event-bus-service:8004")
+	v.SetDefault("audit.topic", "audit-log")
+	v.SetDefault("audit.buffer_size", 1000)
+	v.SetDefault("audit.timeout", "5s")
+	v.SetDefault("audit.retry_attempts", 3)
+	v.SetDefault("audit.retry_backoff", "500ms")
+
+	// Form admin defaults
+	v.SetDefault("form_admin.form_service_url", "http://form-service:8001")
+	v.SetDefault("form_admin.service_token", "")
+	v.SetDefault("form_admin.timeout", "10s")
+
+	// Cache clear defaults
+	v.SetDefault("cache_clear.min_interval", "30s")
+
+	// Feature flag defaults
+	v.SetDefault("feature_flags.redis_url", "redis://localhost:6379/0")
+	v.SetDefault("feature_flags.redis_key", "feature_flags")
+	v.SetDefault("feature_flags.flags", "")
+	v.SetDefault("feature_flags.refresh_interval", "5s")
+
+	// Error reporting defaults
+	v.SetDefault("error_reporting.provider", "noop")
+	v.SetDefault("error_reporting.dsn", "")
+	v.SetDefault("error_reporting.burst", 10)
+	v.SetDefault("error_reporting.interval", "1m")
+	v.SetDefault("error_reporting.sample_rate", 0)
+
+	// Fault injection defaults
+	v.SetDefault("fault_injection.enabled", false)
+	v.SetDefault("fault_injection.token", "")
+	v.SetDefault("fault_injection.max_duration", "1h")
+
+	// Load shedding defaults
+	v.SetDefault("load_shedding.enabled", false)
+	v.SetDefault("load_shedding.max_in_flight", 2000)
+	v.SetDefault("load_shedding.anonymous_in_flight", 1200)
+	v.SetDefault("load_shedding.non_critical_in_flight", 1600)
+	v.SetDefault("load_shedding.latency_window", "30s")
+	v.SetDefault("load_shedding.retry_after", "5s")
+	v.SetDefault("load_shedding.exempt_paths", []string{"/health", "/ready", "/metrics", "/api/v1/health", "/api/v1/metrics", "/api/v1/admin", "/api/gateway"})
+
 	// Proxy defaults
-	v.SetDefault("proxy.timeout", 30)
-	v.SetDefault("proxy.keep_alive", 60)
+	v.SetDefault("proxy.timeout", "30s")
+	v.SetDefault("proxy.keep_alive", "60s")
 	v.SetDefault("proxy.max_idle_conns", 100)
 	v.SetDefault("proxy.max_conns_per_host", 100)
-	v.SetDefault("proxy.idle_conn_timeout", 90)
+	v.SetDefault("proxy.idle_conn_timeout", "90s")
+	v.SetDefault("proxy.coalesce", true)
+	v.SetDefault("proxy.coalesce_max_body_bytes", 1<<20)
+	v.SetDefault("proxy.cache", true)
+	v.SetDefault("proxy.cache_max_entries", 10000)
+	v.SetDefault("proxy.cache_max_body_bytes", 1<<20)
+	v.SetDefault("proxy.cache_invalidation.enabled", false)
+	v.SetDefault("proxy.cache_invalidation.redis_url", "redis://localhost:6379/0")
+	v.SetDefault("proxy.cache_invalidation.channel", "gateway:cache:invalidations")
+	v.SetDefault("proxy.cache_invalidation.coalesce_window", "100ms")
+
+	// Policy defaults, applied to routes without a policy of their own
+	v.SetDefault("policies.default.timeout", "30s")
+	v.SetDefault("policies.default.retry_attempts", 0)
+	v.SetDefault("policies.default.breaker.failure_threshold", 5)
+	v.SetDefault("policies.default.breaker.recovery_timeout", "30s")
+	v.SetDefault("policies.default.max_body_bytes", 10<<20)
+	v.SetDefault("policies.default.cache_ttl", "0s")
 
 	// Logger defaults
 	v.SetDefault("log.level", "info")
@@ -307,23 +715,312 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("cors.allowed_origins", []string{"*"})
 	v.SetDefault("cors.allowed_methods", []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"})
 	v.SetDefault("cors.allowed_headers", []string{"Origin", "Content-Type", "Accept", "Authorization"})
-	v.SetDefault("cors.exposed_headers", []string{"Content-Length", "Content-Type"})
+	v.SetDefault("cors.exposed_headers", []string{"Content-Length", "Content-Type", "X-Request-ID", "X-Upstream-Request-ID", "traceparent", "Server-Timing"})
 	v.SetDefault("cors.allow_credentials", true)
 	v.SetDefault("cors.max_age", 86400)
 }
 
-// validateConfig validates the configuration
-func validateConfig(cfg *Config) error {
-	// Basic validation
-	port, err := strconv.Atoi(cfg.Server.Port)
-	if err != nil || port <= 0 || port > 65535 {
-		return fmt.Errorf("invalid server port: %s", cfg.Server.Port)
+// Validate checks the configuration, including settings that depend on each
+// other, and returns an error listing every problem found
+func (c *Config) Validate() error {
+	var errs []error
+	addf := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
 	}
 
-	// For simplicity, we'll skip complex validation for now
-	// In a production environment, you would use a validation library like go-playground/validator
+	switch c.Environment {
+	case "development", "test", "staging", "production":
+	default:
+		addf("environment %q is not one of development, test, staging or production", c.Environment)
+	}
+
+	// Server
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port <= 0 || port > 65535 {
+		addf("server port %q is not a port from 1 to 65535", c.Server.Port)
+	}
+	if c.Server.ReadTimeout <= 0 || c.Server.WriteTimeout <= 0 || c.Server.IdleTimeout <= 0 {
+		addf("server read, write and idle timeouts must be positive")
+	}
+	if c.Server.IdleTimeout > 0 && c.Server.ReadTimeout >= c.Server.IdleTimeout {
+		addf("server read timeout must be shorter than the idle timeout")
+	}
+	if c.Server.Shutdown.PreStopDelay < 0 || c.Server.Shutdown.DrainTimeout < 0 {
+		addf("server shutdown pre_stop_delay and drain_timeout must not be negative")
+	}
+	if c.Server.TLS.Enabled {
+		if c.Server.TLS.CertFile == "" || c.Server.TLS.KeyFile == "" {
+			addf("server TLS needs both cert_file and key_file when enabled")
+		}
+	}
+	switch c.Server.TLS.MinVersion {
+	case "", "1.2", "1.3":
+	default:
+		addf("server TLS min_version %q is not one of 1.2 or 1.3", c.Server.TLS.MinVersion)
+	}
 
-	return nil
+	// JWT
+	jwtConfig := c.Security.JWT
+	switch jwtConfig.Algorithm {
+	case "HS256", "HS384", "HS512":
+		if c.IsProduction() && len(jwtConfig.Secret) < 32 {
+			addf("JWT secret must be at least 32 characters in production")
+		}
+	case "RS256", "RS384", "RS512":
+		if jwtConfig.PublicKey == "" {
+			addf("JWT public_key is required by the %s algorithm", jwtConfig.Algorithm)
+		}
+	default:
+		addf("JWT algorithm %q is not one of HS256, HS384, HS512, RS256, RS384 or RS512", jwtConfig.Algorithm)
+	}
+
+	// Rate limiting
+	if rateLimit := c.Security.RateLimit; rateLimit.Enabled {
+		if rateLimit.RPS < 1 {
+			addf("rate limit rps must be at least 1 when rate limiting is enabled")
+		}
+		if rateLimit.Burst < rateLimit.RPS {
+			addf("rate limit burst (%d) must be at least the rps (%d)", rateLimit.Burst, rateLimit.RPS)
+		}
+	}
+	tiers := make([]string, 0, len(c.Security.RateLimit.Tiers))
+	for tier := range c.Security.RateLimit.Tiers {
+		tiers = append(tiers, tier)
+	}
+	sort.Strings(tiers)
+	for _, tier := range tiers {
+		if limit := c.Security.RateLimit.Tiers[tier]; limit.RPS < 1 || limit.Window <= 0 {
+			addf("rate limit tier %s needs an rps of at least 1 and a positive window", tier)
+		}
+	}
+	clients := make([]string, 0, len(c.Security.RateLimit.Overrides))
+	for client := range c.Security.RateLimit.Overrides {
+		clients = append(clients, client)
+	}
+	sort.Strings(clients)
+	for _, client := range clients {
+		kind, id, _ := strings.Cut(client, ":")
+		if (kind != "apikey" && kind != "user" && kind != "org") || id == "" {
+			addf("rate limit override %s must name a client as apikey:<id>, user:<id> or org:<id>", client)
+		}
+		if limit := c.Security.RateLimit.Overrides[client]; limit.RPS < 1 || limit.Window <= 0 {
+			addf("rate limit override %s needs an rps of at least 1 and a positive window", client)
+		}
+	}
+
+	// mTLS to the internal services
+	if mtls := c.Security.MTLS; mtls.Enabled {
+		if mtls.CertFile == "" || mtls.KeyFile == "" || mtls.CAFile == "" {
+			addf("mTLS needs cert_file, key_file and ca_file when enabled")
+		}
+		services := make([]string, 0, len(mtls.Identities))
+		for service := range mtls.Identities {
+			services = append(services, service)
+		}
+		sort.Strings(services)
+		for _, service := range services {
+			if u, err := url.Parse(mtls.Identities[service]); err != nil || u.Scheme != "spiffe" || u.Host == "" {
+				addf("mTLS identity of %s %q is not a spiffe:// ID", service, mtls.Identities[service])
+			}
+		}
+	} else if mtls.Enforce {
+		addf("mTLS cannot be enforced when it is not enabled")
+	}
+
+	// Connection URLs
+	redisURLs := []struct{ name, url string }{
+		{"security.api_keys.redis_url", c.Security.APIKeys.RedisURL},
+		{"security.rate_limit.redis_url", c.Security.RateLimit.RedisURL},
+		{"maintenance.redis_url", c.Maintenance.RedisURL},
+		{"registry.redis_url", c.Registry.RedisURL},
+		{"privacy.redis_url", c.Privacy.RedisURL},
+		{"feature_flags.redis_url", c.FeatureFlags.RedisURL},
+		{"proxy.cache_invalidation.redis_url", c.Proxy.CacheInvalidation.RedisURL},
+	}
+	for _, redis := range redisURLs {
+		if redis.url == "" {
+			continue
+		}
+		if u, err := url.Parse(redis.url); err != nil || (u.Scheme != "redis" && u.Scheme != "rediss") || u.Host == "" {
+			addf("%s %q is not a redis:// URL", redis.name, redis.url)
+		}
+	}
+	serviceNames := make([]string, 0, len(c.Services.Services))
+	for name := range c.Services.Services {
+		serviceNames = append(serviceNames, name)
+	}
+	sort.Strings(serviceNames)
+	for _, name := range serviceNames {
+		if service := c.Services.Services[name]; !isAbsoluteURL(service.URL) {
+			addf("service %s url %q is not an absolute URL", name, service.URL)
+		}
+	}
+	if c.Auth.ServiceURL != "" && !isAbsoluteURL(c.Auth.ServiceURL) {
+		addf("auth service_url %q is not an absolute URL", c.Auth.ServiceURL)
+	}
+
+	// Registry overrides
+	if registry := c.Registry; registry.RedisURL != "" {
+		if registry.Key == "" {
+			addf("registry key is required")
+		}
+		if registry.RefreshInterval < time.Second {
+			addf("registry refresh_interval must be at least 1s")
+		}
+	}
+
+	// Privacy requests
+	if privacy := c.Privacy; privacy.EventBusURL != "" || privacy.CallbackToken != "" {
+		if !isAbsoluteURL(privacy.EventBusURL) {
+			addf("privacy event_bus_url %q is not an absolute URL", privacy.EventBusURL)
+		}
+		if privacy.Timeout <= 0 {
+			addf("privacy timeout must be positive")
+		}
+		if len(privacy.ErasureParticipants) == 0 || len(privacy.ExportParticipants) == 0 {
+			addf("privacy erasure_participants and export_participants must not be empty")
+		}
+	}
+
+	// Audit events
+	if audit := c.Audit; audit.Enabled {
+		if !isAbsoluteURL(audit.EventBusURL) {
+			addf("audit event_bus_url %q is not an absolute URL", audit.EventBusURL)
+		}
+		if audit.Topic == "" {
+			addf("audit topic is required")
+		}
+		if audit.BufferSize <= 0 || audit.Timeout <= 0 {
+			addf("audit buffer_size and timeout must be positive")
+		}
+		if audit.RetryAttempts < 0 {
+			addf("audit retry_attempts must not be negative")
+		}
+	}
+
+	// Form admin operations
+	if !isAbsoluteURL(c.FormAdmin.FormServiceURL) {
+		addf("form_admin form_service_url %q is not an absolute URL", c.FormAdmin.FormServiceURL)
+	}
+	if c.FormAdmin.Timeout <= 0 {
+		addf("form_admin timeout must be positive")
+	}
+	if c.CacheClear.MinInterval <= 0 {
+		addf("cache_clear min_interval must be positive")
+	}
+
+	// Feature flags
+	if _, err := flags.Parse(c.FeatureFlags.Flags); err != nil {
+		addf("feature_flags flags: %v", err)
+	}
+	if c.FeatureFlags.RefreshInterval < time.Second {
+		addf("feature_flags refresh_interval must be at least 1s")
+	}
+
+	// Error reporting
+	switch reporting := c.ErrorReporting; {
+	case reporting.Provider != "noop" && reporting.Provider != "sentry":
+		addf("error_reporting provider %q is not one of noop or sentry", reporting.Provider)
+	case reporting.Provider == "sentry" && reporting.DSN == "":
+		addf("error_reporting dsn is required by the sentry provider")
+	}
+	if c.ErrorReporting.Burst < 1 || c.ErrorReporting.Interval <= 0 {
+		addf("error_reporting burst and interval must be positive")
+	}
+	if rate := c.ErrorReporting.SampleRate; rate < 0 || rate > 1 {
+		addf("error_reporting sample_rate must be from 0 to 1")
+	}
+
+	// Cache invalidation
+	if invalidation := c.Proxy.CacheInvalidation; invalidation.Enabled {
+		if !c.Proxy.Cache {
+			addf("proxy cache_invalidation needs the cache enabled")
+		}
+		if invalidation.RedisURL == "" || invalidation.Channel == "" {
+			addf("proxy cache_invalidation needs a redis_url and a channel")
+		}
+		if invalidation.CoalesceWindow < 0 || invalidation.CoalesceWindow > 5*time.Second {
+			addf("proxy cache_invalidation coalesce_window must be from 0 to 5s")
+		}
+	}
+
+	// Load shedding
+	if shedding := c.LoadShedding; shedding.Enabled {
+		if shedding.MaxInFlight < 1 {
+			addf("load_shedding max_in_flight must be at least 1")
+		}
+		if shedding.AnonymousInFlight < 0 || shedding.AnonymousInFlight > shedding.MaxInFlight ||
+			shedding.NonCriticalInFlight < 0 || shedding.NonCriticalInFlight > shedding.MaxInFlight {
+			addf("load_shedding anonymous_in_flight and non_critical_in_flight must be from 0 to max_in_flight")
+		}
+		if shedding.LatencyWindow < time.Second || shedding.LatencyWindow > 15*time.Minute {
+			addf("load_shedding latency_window must be from 1s to 15m")
+		}
+		if shedding.RetryAfter < time.Second {
+			addf("load_shedding retry_after must be at least 1s")
+		}
+		names := make(map[string]bool)
+		for i, class := range shedding.Classes {
+			switch {
+			case class.Name == "" || class.Name == "default":
+				addf("load_shedding class %d needs a name other than default", i)
+			case names[class.Name]:
+				addf("load_shedding class %s is declared twice", class.Name)
+			}
+			names[class.Name] = true
+			if len(class.Prefixes) == 0 {
+				addf("load_shedding class %s needs at least one prefix", class.Name)
+			}
+			for _, prefix := range class.Prefixes {
+				if !strings.HasPrefix(prefix, "/") {
+					addf("load_shedding class %s prefix %q must start with /", class.Name, prefix)
+				}
+			}
+			if class.LatencyTarget < 0 {
+				addf("load_shedding class %s latency_target must not be negative", class.Name)
+			}
+		}
+	}
+
+	// Fault injection
+	if faults := c.FaultInjection; faults.Enabled {
+		if c.IsProduction() {
+			addf("fault_injection cannot be enabled in production")
+		}
+		if faults.Token == "" {
+			addf("fault_injection token is required when fault injection is enabled")
+		}
+		if faults.MaxDuration < time.Minute || faults.MaxDuration > 24*time.Hour {
+			addf("fault_injection max_duration must be from 1m to 24h")
+		}
+	}
+
+	// Request validation
+	if c.Validation.OpenAPI.Enabled {
+		for _, group := range c.Validation.OpenAPI.RouteGroups {
+			if !strings.HasPrefix(group, "/") {
+				addf("OpenAPI route group %q must start with /", group)
+			}
+		}
+	}
+
+	// Logging
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error", "fatal", "panic":
+	default:
+		addf("log level %q is not one of debug, info, warn, error, fatal or panic", c.Log.Level)
+	}
+	switch c.Log.Format {
+	case "json", "text":
+	default:
+		addf("log format %q is not one of json or text", c.Log.Format)
+	}
+
+	return errors.Join(errs...)
+}
+
+func isAbsoluteURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && u.Scheme != "" && u.Host != ""
 }
 
 // CircuitBreakerConfig holds circuit breaker configuration
@@ -350,6 +1047,16 @@ type CircuitBreakerConfig struct {
 type ValidationConfig struct {
 	Enabled bool                      `mapstructure:"enabled"`
 	Rules   map[string]ValidationRule `mapstructure:"rules"`
+	OpenAPI OpenAPIValidationConfig   `mapstructure:"openapi"`
+}
+
+// OpenAPIValidationConfig holds request validation against the OpenAPI spec
+type OpenAPIValidationConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// SpecPath points at a Swagger 2.0 JSON document; the generated gateway docs are used when empty
+	SpecPath string `mapstructure:"spec_path"`
+	// RouteGroups lists the path prefixes whose requests are validated
+	RouteGroups []string `mapstructure:"route_groups"`
 }
 
 // ValidationRule holds validation rules for specific endpoints
@@ -425,8 +1132,8 @@ func LoadConfig() (*Config, error) {
 	}
 
 	// Validate configuration
-	if err := validateConfig(&config); err != nil {
-		return nil, fmt.Errorf("config validation failed: %w", err)
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("config validation failed:\n%w", err)
 	}
 
 	return &config, nil
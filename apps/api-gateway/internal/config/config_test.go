@@ -0,0 +1,235 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func validConfig() *Config {
+	return &Config{
+		Environment: "development",
+		Server: ServerConfig{
+			Port:         "8080",
+			ReadTimeout:  30 * time.Second,
+			WriteTimeout: 30 * time.Second,
+			IdleTimeout:  60 * time.Second,
+		},
+		Security: SecurityConfig{
+			JWT:       JWTConfig{Algorithm: "HS256"},
+			RateLimit: RateLimitConfig{Enabled: true, RPS: 100, Burst: 200},
+			APIKeys:   APIKeyConfig{RedisURL: "redis://localhost:6379/0"},
+		},
+		Services: ServicesConfig{
+			Services: map[string]ServiceConfig{
+				"form-service": {URL: "http://form-service:8001"},
+			},
+		},
+		Validation: ValidationConfig{
+			OpenAPI: OpenAPIValidationConfig{Enabled: true, RouteGroups: []string{"/api/v1"}},
+		},
+		Log:         LogConfig{Level: "info", Format: "json"},
+		Maintenance: MaintenanceConfig{RedisURL: "redis://localhost:6379/0"},
+		Registry:    RegistryConfig{RedisURL: "redis://localhost:6379/0", Key: "gateway:registry:overrides", RefreshInterval: 5 * time.Second},
+		Privacy: PrivacyConfig{
+			RedisURL:            "redis://localhost:6379/0",
+			EventBusURL:         "http://event-bus-service:8004",
+			Timeout:             10 * time.Second,
+			ErasureParticipants: []string{"form-service", "response-store", "collaboration-service"},
+			ExportParticipants:  []string{"form-service", "response-store"},
+		},
+		FormAdmin:      FormAdminConfig{FormServiceURL: "http://form-service:8001", Timeout: 10 * time.Second},
+		CacheClear:     CacheClearConfig{MinInterval: 30 * time.Second},
+		FeatureFlags:   FeatureFlagsConfig{RedisURL: "redis://localhost:6379/0", RedisKey: "feature_flags", RefreshInterval: 5 * time.Second},
+		ErrorReporting: ErrorReportingConfig{Provider: "noop", Burst: 10, Interval: time.Minute},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*Config)
+		want   []string
+	}{
+		{"valid", func(*Config) {}, nil},
+		{"unknown environment", func(c *Config) { c.Environment = "qa" }, []string{`environment "qa"`}},
+		{"port out of range", func(c *Config) { c.Server.Port = "65536" }, []string{`server port "65536"`}},
+		{"missing timeout", func(c *Config) { c.Server.WriteTimeout = 0 }, []string{"timeouts must be positive"}},
+		{"read timeout not below idle timeout", func(c *Config) { c.Server.ReadTimeout = c.Server.IdleTimeout }, []string{"read timeout must be shorter than the idle timeout"}},
+		{"negative drain timeout", func(c *Config) { c.Server.Shutdown.DrainTimeout = -time.Second }, []string{"drain_timeout must not be negative"}},
+		{"TLS without key", func(c *Config) {
+			c.Server.TLS = TLSConfig{Enabled: true, CertFile: "server.crt"}
+		}, []string{"needs both cert_file and key_file"}},
+		{"unknown TLS version", func(c *Config) { c.Server.TLS.MinVersion = "1.1" }, []string{`min_version "1.1"`}},
+		{"unknown JWT algorithm", func(c *Config) { c.Security.JWT.Algorithm = "none" }, []string{`JWT algorithm "none"`}},
+		{"RSA without public key", func(c *Config) { c.Security.JWT.Algorithm = "RS256" }, []string{"public_key is required by the RS256 algorithm"}},
+		{"short secret in production", func(c *Config) {
+			c.Environment = "production"
+			c.Security.JWT.Secret = "secret"
+		}, []string{"at least 32 characters in production"}},
+		{"production with secret", func(c *Config) {
+			c.Environment = "production"
+			c.Security.JWT.Secret = strings.Repeat("s", 32)
+		}, nil},
+		{"burst below rps", func(c *Config) { c.Security.RateLimit.Burst = 10 }, []string{"burst (10) must be at least the rps (100)"}},
+		{"disabled rate limit is not checked", func(c *Config) {
+			c.Security.RateLimit = RateLimitConfig{Enabled: false}
+		}, nil},
+		{"redis URL without scheme", func(c *Config) { c.Maintenance.RedisURL = "localhost:6379" }, []string{`maintenance.redis_url "localhost:6379"`}},
+		{"registry overrides refreshed too often", func(c *Config) { c.Registry.RefreshInterval = 100 * time.Millisecond }, []string{"registry refresh_interval must be at least 1s"}},
+		{"fault injection in production", func(c *Config) {
+			c.Environment = "production"
+			c.FaultInjection = FaultInjectionConfig{Enabled: true, Token: "chaos", MaxDuration: time.Hour}
+		}, []string{"fault_injection cannot be enabled in production"}},
+		{"fault injection without token", func(c *Config) {
+			c.FaultInjection = FaultInjectionConfig{Enabled: true, MaxDuration: time.Hour}
+		}, []string{"fault_injection token is required"}},
+		{"service URL", func(c *Config) {
+			c.Services.Services["response-service"] = ServiceConfig{URL: "response-service:3002"}
+		}, []string{`service response-service url "response-service:3002"`}},
+		{"mTLS without files", func(c *Config) {
+			c.Security.MTLS = MTLSConfig{Enabled: true, CertFile: "gateway.crt"}
+		}, []string{"needs cert_file, key_file and ca_file"}},
+		{"mTLS identity not SPIFFE", func(c *Config) {
+			c.Security.MTLS = MTLSConfig{
+				Enabled: true, CertFile: "gateway.crt", KeyFile: "gateway.key", CAFile: "ca.crt",
+				Identities: map[string]string{"form-service": "form-service.internal"},
+			}
+		}, []string{`identity of form-service "form-service.internal"`}},
+		{"mTLS enforced but disabled", func(c *Config) { c.Security.MTLS.Enforce = true }, []string{"cannot be enforced"}},
+		{"mTLS with identities", func(c *Config) {
+			c.Security.MTLS = MTLSConfig{
+				Enabled: true, Enforce: true, CertFile: "gateway.crt", KeyFile: "gateway.key", CAFile: "ca.crt",
+				Identities: map[string]string{"form-service": "spiffe://xform.internal/form-service"},
+			}
+		}, nil},
+		{"rate limit tier without window", func(c *Config) {
+			c.Security.RateLimit.Tiers = map[string]EndpointRateLimit{"reports": {RPS: 5}}
+		}, []string{"rate limit tier reports needs"}},
+		{"rate limit overrides", func(c *Config) {
+			c.Security.RateLimit.Overrides = map[string]EndpointRateLimit{
+				"apikey:k1": {RPS: 1000, Window: time.Minute},
+				"team:t1":   {RPS: 1000, Window: time.Minute},
+				"user:u1":   {RPS: 10},
+				"org:":      {RPS: 10, Window: time.Minute},
+			}
+		}, []string{"override org: must name a client", "override team:t1 must name a client", "override user:u1 needs"}},
+		{"privacy without participants", func(c *Config) {
+			c.Privacy.EventBusURL = "event-bus-service:8004"
+			c.Privacy.ExportParticipants = nil
+		}, []string{`privacy event_bus_url "event-bus-service:8004"`, "export_participants must not be empty"}},
+		{"audit without buffer", func(c *Config) {
+			c.Audit = AuditConfig{Enabled: true, EventBusURL: "http://event-bus-service:8004", Topic: "audit-log", Timeout: 5 * time.Second}
+		}, []string{"audit buffer_size and timeout must be positive"}},
+		{"form admin without timeout", func(c *Config) {
+			c.FormAdmin = FormAdminConfig{FormServiceURL: "form-service:8001"}
+		}, []string{`form_admin form_service_url "form-service:8001"`, "form_admin timeout must be positive"}},
+		{"cache clear without interval", func(c *Config) { c.CacheClear.MinInterval = 0 }, []string{"cache_clear min_interval must be positive"}},
+		{"load shedding thresholds above the limit", func(c *Config) {
+			c.LoadShedding = LoadSheddingConfig{Enabled: true, MaxInFlight: 100, AnonymousInFlight: 150, LatencyWindow: 30 * time.Second, RetryAfter: 5 * time.Second}
+		}, []string{"anonymous_in_flight and non_critical_in_flight must be from 0 to max_in_flight"}},
+		{"load shedding classes", func(c *Config) {
+			c.LoadShedding = LoadSheddingConfig{Enabled: true, MaxInFlight: 100, Classes: []RouteClassConfig{
+				{Name: "submissions", Prefixes: []string{"/api/v1/responses"}},
+				{Name: "submissions", Prefixes: []string{"api/v1/public"}},
+				{Name: "default", Prefixes: []string{"/"}},
+			}}
+		}, []string{"latency_window must be from 1s to 15m", "retry_after must be at least 1s", "class submissions is declared twice", `prefix "api/v1/public"`, "class 2 needs a name other than default"}},
+		{"feature flags", func(c *Config) {
+			c.FeatureFlags.Flags = `[{"name":"new_validator","percentage":150}]`
+			c.FeatureFlags.RefreshInterval = 100 * time.Millisecond
+		}, []string{"feature_flags flags: flag new_validator: percentage must be from 0 to 100", "refresh_interval must be at least 1s"}},
+		{"defined feature flags", func(c *Config) {
+			c.FeatureFlags.Flags = `[{"name":"new_validator","enabled":true,"percentage":10}]`
+		}, nil},
+		{"error reporting", func(c *Config) {
+			c.ErrorReporting = ErrorReportingConfig{Provider: "sentry", Interval: time.Minute, SampleRate: -1}
+		}, []string{"error_reporting dsn is required", "burst and interval must be positive", "sample_rate must be from 0 to 1"}},
+		{"cache invalidation without the cache", func(c *Config) {
+			c.Proxy.CacheInvalidation = CacheInvalidationConfig{Enabled: true, RedisURL: "localhost:6379", CoalesceWindow: time.Minute}
+		}, []string{"needs the cache enabled", "needs a redis_url and a channel", `proxy.cache_invalidation.redis_url "localhost:6379"`, "coalesce_window must be from 0 to 5s"}},
+		{"cache invalidation", func(c *Config) {
+			c.Proxy.Cache = true
+			c.Proxy.CacheInvalidation = CacheInvalidationConfig{Enabled: true, RedisURL: "redis://localhost:6379/0", Channel: "gateway:cache:invalidations", CoalesceWindow: 100 * time.Millisecond}
+		}, nil},
+		{"route group without slash", func(c *Config) {
+			c.Validation.OpenAPI.RouteGroups = []string{"api/v1"}
+		}, []string{`route group "api/v1"`}},
+		{"unknown log level and format", func(c *Config) {
+			c.Log = LogConfig{Level: "trace", Format: "xml"}
+		}, []string{`log level "trace"`, `log format "xml"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.mutate(cfg)
+			err := cfg.Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected errors mentioning %q", tt.want)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error does not mention %q:\n%v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	cfg := validConfig()
+	cfg.Environment = "qa"
+	cfg.Server.Port = "http"
+	cfg.Security.JWT.Algorithm = "none"
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	want := strings.Join([]string{
+		`environment "qa" is not one of development, test, staging or production`,
+		`server port "http" is not a port from 1 to 65535`,
+		`JWT algorithm "none" is not one of HS256, HS384, HS512, RS256, RS384 or RS512`,
+	}, "\n")
+	if err.Error() != want {
+		t.Errorf("got:\n%v\nwant:\n%s", err, want)
+	}
+}
+
+func TestLoadShippedConfigs(t *testing.T) {
+	t.Setenv("CONFIG_PATH", "../../config")
+
+	// config.yaml is the production configuration; the secret comes from the environment
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "JWT secret") {
+		t.Errorf("expected production config without a JWT secret to be rejected, got %v", err)
+	}
+	t.Setenv("API_GATEWAY_SECURITY_JWT_SECRET", strings.Repeat("s", 32))
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("config.yaml is invalid:\n%v", err)
+	}
+	// Route policies inherit what they leave out, so a zero setting must be
+	// told apart from a missing one
+	if forms := cfg.Policies.Routes["/forms/:id"]; forms.RetryAttempts == nil || *forms.RetryAttempts != 2 ||
+		forms.CacheTTL == nil || *forms.CacheTTL != 30*time.Second || forms.MaxBodyBytes != nil {
+		t.Errorf("policy of /forms/:id = %+v", forms)
+	}
+	if cfg.Policies.Default.CacheTTL == nil || *cfg.Policies.Default.CacheTTL != 0 {
+		t.Errorf("default cache_ttl = %v, want 0s", cfg.Policies.Default.CacheTTL)
+	}
+	if tier := cfg.Security.RateLimit.Tiers["submissions"]; tier.RPS != 20 || tier.Window != time.Minute || !cfg.Security.RateLimit.Enabled {
+		t.Errorf("submissions tier = %+v, rate limit enabled %v", tier, cfg.Security.RateLimit.Enabled)
+	}
+
+	t.Setenv("CONFIG_NAME", "dev")
+	if _, err := Load(); err != nil {
+		t.Errorf("dev.yaml is invalid:\n%v", err)
+	}
+}
@@ -0,0 +1,121 @@
+// Package drain shuts the gateway down without cutting requests: on SIGTERM
+// readiness turns to 503 so the load balancer stops routing to the gateway,
+// requests are still served for a pre-stop delay, then the listener is closed
+// and in-flight requests are given a drain timeout to complete.
+package drain
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/config"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
+)
+
+// Drainer tracks the requests in flight and the readiness of the server
+type Drainer struct {
+	preStopDelay time.Duration
+	drainTimeout time.Duration
+	logger       logger.Logger
+
+	draining atomic.Bool
+	inFlight atomic.Int64
+}
+
+// Result reports how the requests in flight at shutdown ended
+type Result struct {
+	// Drained requests completed within the drain timeout
+	Drained int64
+	// Aborted requests were still running at the drain timeout
+	Aborted int64
+}
+
+// New creates a drainer with the delays of cfg
+func New(cfg config.ShutdownConfig, logger logger.Logger) *Drainer {
+	return &Drainer{
+		preStopDelay: cfg.PreStopDelay,
+		drainTimeout: cfg.DrainTimeout,
+		logger:       logger,
+	}
+}
+
+// Track counts the requests in flight through next
+func (d *Drainer) Track(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d.inFlight.Add(1)
+		defer d.inFlight.Add(-1)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Draining reports whether shutdown has started
+func (d *Drainer) Draining() bool {
+	return d.draining.Load()
+}
+
+// InFlight returns the number of requests being served
+func (d *Drainer) InFlight() int64 {
+	return d.inFlight.Load()
+}
+
+// ReadinessHandler answers 200 while the gateway takes traffic, and 503 from
+// the moment shutdown starts. Liveness stays on /health, which keeps passing
+// while the gateway drains.
+func (d *Drainer) ReadinessHandler(w http.ResponseWriter, r *http.Request) {
+	status, code := "ready", http.StatusOK
+	if d.Draining() {
+		status, code = "draining", http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    status,
+		"in_flight": d.InFlight(),
+	})
+}
+
+// Shutdown drains server: readiness turns to 503 at once, requests are served
+// for the pre-stop delay, then the server shuts down, waiting up to the drain
+// timeout for the requests in flight before closing their connections.
+// closeIdle is called last, to release the idle upstream connections.
+func (d *Drainer) Shutdown(server *http.Server, closeIdle ...func()) Result {
+	d.draining.Store(true)
+	// Responses ask clients to reconnect, to another replica
+	server.SetKeepAlivesEnabled(false)
+	d.logger.Infof("Draining: readiness reports 503, serving %d requests in flight for %s more", d.InFlight(), d.preStopDelay)
+	time.Sleep(d.preStopDelay)
+
+	pending := d.InFlight()
+	ctx := context.Background()
+	if d.drainTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.drainTimeout)
+		defer cancel()
+	}
+	err := server.Shutdown(ctx)
+
+	var result Result
+	if err != nil {
+		// Requests still running are cut with their connections
+		result.Aborted = d.InFlight()
+		server.Close()
+	}
+	result.Drained = pending - result.Aborted
+	if result.Drained < 0 {
+		result.Drained = 0
+	}
+
+	for _, close := range closeIdle {
+		close()
+	}
+
+	if result.Aborted > 0 {
+		d.logger.Warnf("Drained %d requests, aborted %d still running after %s", result.Drained, result.Aborted, d.drainTimeout)
+	} else {
+		d.logger.Infof("Drained %d requests", result.Drained)
+	}
+	return result
+}
@@ -0,0 +1,149 @@
+//go:build unix
+
+package drain
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/config"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
+)
+
+// startServer serves a slow endpoint taking slow to answer, and readiness,
+// shutting down through the drainer on SIGTERM
+func startServer(t *testing.T, cfg config.ShutdownConfig, slow time.Duration) (string, *Drainer, chan struct{}, <-chan Result) {
+	t.Helper()
+	d := New(cfg, logger.New(logger.LogConfig{Level: "fatal", Format: "json", Output: "stderr"}))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ready", d.ReadinessHandler)
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(slow):
+			io.WriteString(w, "done")
+		case <-r.Context().Done():
+		}
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: d.Track(mux)}
+	go server.Serve(listener)
+
+	sigterm := make(chan os.Signal, 1)
+	signal.Notify(sigterm, syscall.SIGTERM)
+	t.Cleanup(func() { signal.Stop(sigterm) })
+
+	closedIdle := make(chan struct{})
+	results := make(chan Result, 1)
+	go func() {
+		<-sigterm
+		results <- d.Shutdown(server, func() { close(closedIdle) })
+	}()
+	return "http://" + listener.Addr().String(), d, closedIdle, results
+}
+
+func TestShutdownDrainsInFlightRequests(t *testing.T) {
+	url, d, closedIdle, results := startServer(t, config.ShutdownConfig{
+		PreStopDelay: 300 * time.Millisecond,
+		DrainTimeout: 5 * time.Second,
+	}, 600*time.Millisecond)
+
+	if resp, err := http.Get(url + "/ready"); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("readiness before shutdown = %v, %v", resp, err)
+	}
+
+	slow := make(chan *http.Response, 1)
+	go func() {
+		resp, err := http.Get(url + "/slow")
+		if err != nil {
+			t.Errorf("the in-flight request failed: %v", err)
+		}
+		slow <- resp
+	}()
+	waitInFlight(t, d)
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+
+	// Readiness fails at once, while the gateway keeps serving
+	deadline := time.Now().Add(time.Second)
+	for !d.Draining() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	resp, err := http.Get(url + "/ready")
+	if err != nil {
+		t.Fatalf("readiness while draining: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("readiness while draining = %d, want 503", resp.StatusCode)
+	}
+
+	if resp := <-slow; resp == nil || resp.StatusCode != http.StatusOK {
+		t.Errorf("in-flight request = %v, want completed", resp)
+	} else {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "done" {
+			t.Errorf("in-flight request body = %q", body)
+		}
+	}
+
+	result := <-results
+	if result.Drained != 1 || result.Aborted != 0 {
+		t.Errorf("result = %+v, want 1 drained", result)
+	}
+	select {
+	case <-closedIdle:
+	default:
+		t.Error("the idle upstream connections were not closed")
+	}
+}
+
+func TestShutdownAbortsRequestsPastTheDrainTimeout(t *testing.T) {
+	url, d, _, results := startServer(t, config.ShutdownConfig{
+		DrainTimeout: 100 * time.Millisecond,
+	}, time.Minute)
+
+	go func() {
+		if resp, err := http.Get(url + "/slow"); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	waitInFlight(t, d)
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case result := <-results:
+		if result.Drained != 0 || result.Aborted != 1 {
+			t.Errorf("result = %+v, want 1 aborted", result)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("shutdown did not give up at the drain timeout")
+	}
+}
+
+// waitInFlight waits until a request is being served
+func waitInFlight(t *testing.T, d *Drainer) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for d.InFlight() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the request never reached the server")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,395 @@
+// Package faults injects faults into the requests the gateway proxies, so
+// its retries, circuit breakers and timeouts can be exercised outside
+// production without breaking a service. Admins configure rules at runtime:
+// a rule matches gateway paths by pattern and faults each upstream attempt
+// with its probability until it expires. Faults are injected below the
+// retries, so a retried attempt is faulted, or spared, on its own. Every
+// fault injected is logged and counted with fault_injected=true.
+package faults
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/config"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/routes"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/metrics"
+)
+
+// Type is the kind of fault a rule injects
+type Type string
+
+const (
+	// Latency delays the upstream call by LatencyMS, plus up to JitterMS
+	Latency Type = "latency"
+	// Error answers with Status without calling the service
+	Error Type = "error"
+	// Reset fails the upstream call as a connection reset by the service
+	Reset Type = "reset"
+	// Truncate cuts the body of the response of the service after
+	// TruncateBytes
+	Truncate Type = "truncate"
+)
+
+// Header names the rule that faulted a response, on the responses a fault
+// answered or cut
+const Header = "X-Fault-Injected"
+
+// maxLatency bounds the delay a rule injects, jitter included
+const maxLatency = 2 * time.Minute
+
+// ErrInvalidRule is returned for rules that cannot be applied
+var ErrInvalidRule = errors.New("invalid fault rule")
+
+// ErrConnectionReset fails the upstream calls faulted by a Reset rule
+var ErrConnectionReset = fmt.Errorf("fault injected: %w", syscall.ECONNRESET)
+
+// Spec is a rule as admins configure it
+type Spec struct {
+	// Path is a gateway path pattern with :param and *wildcard segments, as
+	// in route policies
+	Path string `json:"path" binding:"required" example:"/forms/*"`
+	Type Type   `json:"type" binding:"required,oneof=latency error reset truncate" example:"error"`
+	// Probability is the chance of each upstream attempt to be faulted,
+	// above 0 and up to 1
+	Probability float64 `json:"probability" binding:"required" example:"0.5"`
+	LatencyMS   int     `json:"latency_ms,omitempty" example:"500"`
+	JitterMS    int     `json:"jitter_ms,omitempty" example:"250"`
+	// Status answers error faults, 503 when omitted
+	Status int `json:"status,omitempty" example:"503"`
+	// TruncateBytes is how much of the body truncate faults let through
+	TruncateBytes int64 `json:"truncate_bytes,omitempty" example:"128"`
+	// DurationSeconds is how long the rule lasts, up to
+	// fault_injection.max_duration
+	DurationSeconds int `json:"duration_seconds" binding:"required" example:"600"`
+} // @name FaultSpec
+
+// Rule is a configured rule, which stops applying at ExpiresAt
+type Rule struct {
+	Spec
+	ID        string    `json:"id" example:"fault-1"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+} // @name FaultRule
+
+// Expired reports whether the rule no longer applies at now
+func (r Rule) Expired(now time.Time) bool {
+	return !now.Before(r.ExpiresAt)
+}
+
+// latency returns the delay of a Latency rule, its jitter drawn by roll
+func (r Rule) latency(roll func() float64) time.Duration {
+	delay := time.Duration(r.LatencyMS) * time.Millisecond
+	if r.JitterMS > 0 {
+		delay += time.Duration(roll() * float64(time.Duration(r.JitterMS)*time.Millisecond))
+	}
+	return delay
+}
+
+// Injector holds the rules and attaches those matching a request to it, for
+// Transport to inject
+type Injector struct {
+	maxDuration time.Duration
+	metrics     *metrics.Collector
+	logger      logger.Logger
+	now         func() time.Time
+	// roll draws a number in [0, 1)
+	roll func() float64
+
+	mu     sync.RWMutex
+	rules  []Rule
+	lastID int
+}
+
+// New creates an injector without rules
+func New(cfg config.FaultInjectionConfig, metrics *metrics.Collector, logger logger.Logger) *Injector {
+	return &Injector{
+		maxDuration: cfg.MaxDuration,
+		metrics:     metrics,
+		logger:      logger,
+		now:         time.Now,
+		roll:        rand.Float64,
+	}
+}
+
+// Set replaces the rules by those of specs, created by actor; no specs clear
+// them. Either every spec is valid and applies, or none does.
+func (i *Injector) Set(specs []Spec, actor string) ([]Rule, error) {
+	for n, spec := range specs {
+		if err := i.validate(&spec); err != nil {
+			return nil, fmt.Errorf("%w: rule %d: %v", ErrInvalidRule, n, err)
+		}
+		specs[n] = spec
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	now := i.now().UTC()
+	rules := make([]Rule, len(specs))
+	for n, spec := range specs {
+		i.lastID++
+		rules[n] = Rule{
+			Spec:      spec,
+			ID:        fmt.Sprintf("fault-%d", i.lastID),
+			CreatedBy: actor,
+			CreatedAt: now,
+			ExpiresAt: now.Add(time.Duration(spec.DurationSeconds) * time.Second),
+		}
+	}
+	i.rules = rules
+	i.logger.Warnf("Fault injection rules set by %s: %d rules", actor, len(rules))
+	return append([]Rule{}, rules...), nil
+}
+
+// validate checks spec, defaulting the status of error faults
+func (i *Injector) validate(spec *Spec) error {
+	if err := validatePattern(spec.Path); err != nil {
+		return fmt.Errorf("path %q: %v", spec.Path, err)
+	}
+	if spec.Probability <= 0 || spec.Probability > 1 {
+		return errors.New("probability must be above 0 and at most 1")
+	}
+	if duration := time.Duration(spec.DurationSeconds) * time.Second; duration <= 0 || duration > i.maxDuration {
+		return fmt.Errorf("duration_seconds must be from 1 to %d", int(i.maxDuration.Seconds()))
+	}
+
+	switch spec.Type {
+	case Latency:
+		latency := time.Duration(spec.LatencyMS+spec.JitterMS) * time.Millisecond
+		if spec.LatencyMS < 0 || spec.JitterMS < 0 || latency <= 0 || latency > maxLatency {
+			return fmt.Errorf("latency_ms and jitter_ms must add up to between 1 and %d", maxLatency.Milliseconds())
+		}
+	case Error:
+		if spec.Status == 0 {
+			spec.Status = http.StatusServiceUnavailable
+		}
+		if spec.Status < 400 || spec.Status > 599 {
+			return errors.New("status must be an error status from 400 to 599")
+		}
+	case Reset:
+	case Truncate:
+		if spec.TruncateBytes < 0 {
+			return errors.New("truncate_bytes must not be negative")
+		}
+	default:
+		return fmt.Errorf("type %q is not one of latency, error, reset or truncate", spec.Type)
+	}
+	return nil
+}
+
+// validatePattern checks a path pattern has the form routes.MatchPath expects
+func validatePattern(pattern string) error {
+	if !strings.HasPrefix(pattern, "/") {
+		return errors.New("pattern must start with /")
+	}
+	segments := strings.Split(strings.Trim(pattern, "/"), "/")
+	for n, segment := range segments {
+		switch {
+		case segment == "" && len(segments) > 1:
+			return errors.New("pattern has an empty segment")
+		case strings.HasPrefix(segment, "*") && n != len(segments)-1:
+			return errors.New("a *wildcard must be the last segment")
+		}
+	}
+	return nil
+}
+
+// Rules returns the rules that have not expired. Expired rules are dropped.
+func (i *Injector) Rules() []Rule {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	now := i.now()
+	active := i.rules[:0]
+	for _, rule := range i.rules {
+		if rule.Expired(now) {
+			i.logger.Infof("Fault injection rule %s on %s expired", rule.ID, rule.Path)
+			continue
+		}
+		active = append(active, rule)
+	}
+	i.rules = active
+	return append([]Rule{}, active...)
+}
+
+// match returns the active rules matching a gateway path
+func (i *Injector) match(path string) []Rule {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	now := i.now()
+	var matched []Rule
+	for _, rule := range i.rules {
+		if !rule.Expired(now) && routes.MatchPath(rule.Path, path) {
+			matched = append(matched, rule)
+		}
+	}
+	return matched
+}
+
+// Attach returns r carrying the rules matching its path, for the transport
+// to the service to inject. It must see the gateway path, before the proxy
+// rewrites it.
+func (i *Injector) Attach(r *http.Request) *http.Request {
+	state := &request{injector: i, rules: i.match(r.URL.Path), path: r.URL.Path}
+	return r.WithContext(context.WithValue(r.Context(), contextKey{}, state))
+}
+
+type contextKey struct{}
+
+// request holds the rules attached to a request, and whether any of them
+// faulted one of its upstream calls
+type request struct {
+	injector *Injector
+	rules    []Rule
+	path     string
+	injected atomic.Bool
+}
+
+// Injected reports whether a fault was injected into an upstream call of the
+// request of ctx
+func Injected(ctx context.Context) bool {
+	state, ok := ctx.Value(contextKey{}).(*request)
+	return ok && state.injected.Load()
+}
+
+// record flags the request faulted, logs the fault and counts it
+func (s *request) record(req *http.Request, service string, rule Rule) {
+	s.injected.Store(true)
+	s.injector.metrics.RecordInjectedFault(service, string(rule.Type))
+	s.injector.logger.WithFields(logger.Fields{
+		"fault_injected": true,
+		"fault_rule":     rule.ID,
+		"fault_type":     rule.Type,
+		"service":        service,
+		"method":         req.Method,
+		"path":           s.path,
+		"request_id":     req.Header.Get("X-Request-ID"),
+	}).Warn("Fault injected")
+}
+
+// transport injects the faults of the rules attached to each request into
+// its calls to a service
+type transport struct {
+	service string
+	next    http.RoundTripper
+}
+
+// Transport wraps the transport to service so that the faults of the rules
+// attached to each request are injected into its calls. Requests without
+// rules go straight through.
+func Transport(service string, next http.RoundTripper) http.RoundTripper {
+	return &transport{service: service, next: next}
+}
+
+// RoundTrip rolls each rule of req once: latencies add up, and the first
+// other fault drawn replaces or alters the call to the service
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	state, ok := req.Context().Value(contextKey{}).(*request)
+	if !ok || len(state.rules) == 0 {
+		return t.next.RoundTrip(req)
+	}
+
+	var delay time.Duration
+	var fault *Rule
+	for n := range state.rules {
+		rule := state.rules[n]
+		if state.injector.roll() >= rule.Probability {
+			continue
+		}
+		if rule.Type == Latency {
+			delay += rule.latency(state.injector.roll)
+			state.record(req, t.service, rule)
+			continue
+		}
+		if fault == nil {
+			fault = &rule
+			state.record(req, t.service, rule)
+		}
+	}
+
+	if delay > 0 {
+		wait := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			wait.Stop()
+			return nil, req.Context().Err()
+		case <-wait.C:
+		}
+	}
+	if fault == nil {
+		return t.next.RoundTrip(req)
+	}
+
+	switch fault.Type {
+	case Error:
+		return errorResponse(req, *fault), nil
+	case Reset:
+		return nil, ErrConnectionReset
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	resp.Header.Set(Header, fault.ID)
+	resp.Body = &truncatedBody{body: resp.Body, remaining: fault.TruncateBytes}
+	return resp, nil
+}
+
+// CloseIdleConnections closes the idle connections of the wrapped transport
+func (t *transport) CloseIdleConnections() {
+	if closer, ok := t.next.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// errorResponse is the response of the service an error fault stands for
+func errorResponse(req *http.Request, rule Rule) *http.Response {
+	body := fmt.Sprintf(`{"error":"fault injected","fault_rule":%q}`, rule.ID)
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", rule.Status, http.StatusText(rule.Status)),
+		StatusCode: rule.Status,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header: http.Header{
+			"Content-Type": {"application/json"},
+			Header:         {rule.ID},
+		},
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// truncatedBody lets remaining bytes of a body through, then fails as a
+// connection cut mid-response
+type truncatedBody struct {
+	body      io.ReadCloser
+	remaining int64
+}
+
+func (b *truncatedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.body.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
+
+func (b *truncatedBody) Close() error {
+	return b.body.Close()
+}
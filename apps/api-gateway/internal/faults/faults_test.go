@@ -0,0 +1,137 @@
+package faults
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/config"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/metrics"
+)
+
+func newInjector(t *testing.T) *Injector {
+	t.Helper()
+	log := logger.New(logger.LogConfig{Level: "fatal", Format: "json", Output: "stderr"})
+	injector := New(config.FaultInjectionConfig{MaxDuration: time.Hour}, metrics.NewCollector(metrics.Config{Enabled: true}), log)
+	// Every rule applies
+	injector.roll = func() float64 { return 0 }
+	return injector
+}
+
+// roundTrip sends a GET of path through the transport of injector to a
+// service answering body
+func roundTrip(t *testing.T, injector *Injector, ctx context.Context, path, body string) (*http.Response, error) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(server.Close)
+
+	r := injector.Attach(httptest.NewRequest(http.MethodGet, path, nil).WithContext(ctx))
+	upstream, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, server.URL, nil)
+	return Transport("form-service", http.DefaultTransport).RoundTrip(upstream)
+}
+
+func TestSetValidatesRules(t *testing.T) {
+	injector := newInjector(t)
+	valid := Spec{Path: "/forms/*", Type: Error, Probability: 0.5, DurationSeconds: 60}
+
+	for name, spec := range map[string]Spec{
+		"relative path":        {Path: "forms/*", Type: Error, Probability: 0.5, DurationSeconds: 60},
+		"inner wildcard":       {Path: "/*/forms", Type: Error, Probability: 0.5, DurationSeconds: 60},
+		"zero probability":     {Path: "/forms/*", Type: Error, DurationSeconds: 60},
+		"probability above 1":  {Path: "/forms/*", Type: Error, Probability: 1.5, DurationSeconds: 60},
+		"over max duration":    {Path: "/forms/*", Type: Error, Probability: 0.5, DurationSeconds: 7200},
+		"success status":       {Path: "/forms/*", Type: Error, Status: 200, Probability: 0.5, DurationSeconds: 60},
+		"latency without time": {Path: "/forms/*", Type: Latency, Probability: 0.5, DurationSeconds: 60},
+		"unknown type":         {Path: "/forms/*", Type: "flood", Probability: 0.5, DurationSeconds: 60},
+	} {
+		if _, err := injector.Set([]Spec{valid, spec}, "admin-1"); !errors.Is(err, ErrInvalidRule) {
+			t.Errorf("%s: err = %v, want ErrInvalidRule", name, err)
+		}
+	}
+	if len(injector.Rules()) != 0 {
+		t.Error("an invalid set applied its valid rules")
+	}
+
+	rules, err := injector.Set([]Spec{valid}, "admin-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules[0].Status != http.StatusServiceUnavailable || rules[0].ID == "" || rules[0].CreatedBy != "admin-1" {
+		t.Errorf("rule = %+v, want a 503 rule created by admin-1", rules[0])
+	}
+}
+
+func TestRulesExpire(t *testing.T) {
+	injector := newInjector(t)
+	now := time.Now()
+	injector.now = func() time.Time { return now }
+	if _, err := injector.Set([]Spec{{Path: "/forms/*", Type: Reset, Probability: 1, DurationSeconds: 60}}, "admin-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := roundTrip(t, injector, context.Background(), "/forms/form-1", "ok"); !errors.Is(err, ErrConnectionReset) {
+		t.Errorf("err = %v, want a connection reset", err)
+	}
+
+	now = now.Add(time.Minute)
+	if rules := injector.Rules(); len(rules) != 0 {
+		t.Errorf("rules = %+v after their duration, want none", rules)
+	}
+	resp, err := roundTrip(t, injector, context.Background(), "/forms/form-1", "ok")
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Errorf("round trip after expiry = %v, %v, want the response of the service", resp, err)
+	}
+}
+
+func TestErrorAndTruncateFaults(t *testing.T) {
+	injector := newInjector(t)
+	if _, err := injector.Set([]Spec{
+		{Path: "/forms/:id", Type: Error, Status: http.StatusBadGateway, Probability: 1, DurationSeconds: 60},
+		{Path: "/responses/*", Type: Truncate, TruncateBytes: 4, Probability: 1, DurationSeconds: 60},
+	}, "admin-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	resp, err := roundTrip(t, injector, ctx, "/forms/form-1", "ok")
+	if err != nil || resp.StatusCode != http.StatusBadGateway || resp.Header.Get(Header) == "" {
+		t.Errorf("error fault = %v, %v, want a tagged 502", resp, err)
+	}
+
+	resp, err = roundTrip(t, injector, ctx, "/responses/export", `{"responses":[]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if string(body) != `{"re` || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("truncated body = %q, %v, want 4 bytes then an unexpected EOF", body, err)
+	}
+
+	// Paths matching no rule go straight through
+	resp, err = roundTrip(t, injector, ctx, "/forms/form-1/questions", "ok")
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Errorf("unmatched path = %v, %v, want the response of the service", resp, err)
+	}
+}
+
+func TestLatencyFaultHonoursDeadline(t *testing.T) {
+	injector := newInjector(t)
+	if _, err := injector.Set([]Spec{{Path: "/forms/*", Type: Latency, LatencyMS: 5000, Probability: 1, DurationSeconds: 60}}, "admin-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := roundTrip(t, injector, ctx, "/forms/form-1", "ok")
+	if !errors.Is(err, context.DeadlineExceeded) || time.Since(start) > time.Second {
+		t.Errorf("err = %v after %v, want the deadline of the request", err, time.Since(start))
+	}
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/audit"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
+)
+
+// AdminHandler serves the gateway's administrative endpoints
+type AdminHandler struct {
+	maintenance *middleware.MaintenanceStore
+	audit       *audit.Recorder
+	logger      logger.Logger
+}
+
+// NewAdminHandler creates a new admin handler. Maintenance changes are
+// recorded to recorder.
+func NewAdminHandler(maintenance *middleware.MaintenanceStore, recorder *audit.Recorder, logger logger.Logger) *AdminHandler {
+	return &AdminHandler{
+		maintenance: maintenance,
+		audit:       recorder,
+		logger:      logger,
+	}
+}
+
+// SetMaintenanceModeRequest represents a request to toggle maintenance mode
+type SetMaintenanceModeRequest struct {
+	Enabled           bool       `json:"enabled"`
+	Message           string     `json:"message" binding:"max=500"`
+	StartsAt          *time.Time `json:"starts_at"`
+	EndsAt            *time.Time `json:"ends_at"`
+	RetryAfterSeconds int        `json:"retry_after_seconds" binding:"min=0"`
+} // @name SetMaintenanceModeRequest
+
+// MaintenanceModeResponse reports the maintenance flag and whether it is in effect
+type MaintenanceModeResponse struct {
+	middleware.MaintenanceState
+	Active bool `json:"active"`
+} // @name MaintenanceModeResponse
+
+// GetMaintenanceMode godoc
+// @Summary Get maintenance mode
+// @Description Report the current maintenance state and who enabled it
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} MaintenanceModeResponse
+// @Router /api/v1/admin/maintenance [get]
+func (h *AdminHandler) GetMaintenanceMode(c *gin.Context) {
+	if err := h.maintenance.Refresh(c.Request.Context()); err != nil {
+		h.logger.Warnf("Failed to refresh maintenance state: %v", err)
+	}
+
+	state := h.maintenance.Current()
+	c.JSON(http.StatusOK, MaintenanceModeResponse{
+		MaintenanceState: state,
+		Active:           state.Active(time.Now()),
+	})
+}
+
+// SetMaintenanceMode godoc
+// @Summary Set maintenance mode
+// @Description Enable or disable maintenance mode for all gateway replicas, optionally within a scheduled window
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body SetMaintenanceModeRequest true "Maintenance settings"
+// @Success 200 {object} MaintenanceModeResponse
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/admin/maintenance [put]
+func (h *AdminHandler) SetMaintenanceMode(c *gin.Context) {
+	var req SetMaintenanceModeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.StartsAt != nil && req.EndsAt != nil && !req.EndsAt.After(*req.StartsAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ends_at must be after starts_at"})
+		return
+	}
+
+	state := middleware.MaintenanceState{
+		Enabled:    req.Enabled,
+		Message:    req.Message,
+		StartsAt:   req.StartsAt,
+		EndsAt:     req.EndsAt,
+		RetryAfter: req.RetryAfterSeconds,
+	}
+	if userID, ok := c.Request.Context().Value(middleware.UserIDKey).(string); ok {
+		state.EnabledBy = userID
+	}
+
+	before := h.maintenance.Current()
+	if err := h.maintenance.Set(c.Request.Context(), state); err != nil {
+		h.logger.Errorf("Failed to set maintenance mode: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update maintenance mode"})
+		return
+	}
+
+	h.logger.Infof("Maintenance mode set to %t by %s", state.Enabled, state.EnabledBy)
+
+	state = h.maintenance.Current()
+	event := auditEvent(c, "maintenance.updated", "gateway_config", "maintenance")
+	event.Before, event.After = before, state
+	h.audit.Record(event)
+	c.JSON(http.StatusOK, MaintenanceModeResponse{
+		MaintenanceState: state,
+		Active:           state.Active(time.Now()),
+	})
+}
@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/apikey"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/audit"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
+)
+
+// APIKeyHandler serves the API key management endpoints
+type APIKeyHandler struct {
+	keys        *apikey.Service
+	rotateGrace time.Duration
+	audit       *audit.Recorder
+	logger      logger.Logger
+}
+
+// NewAPIKeyHandler creates a new API key handler. Rotated secrets stay valid
+// for rotateGrace. Key changes are recorded to recorder.
+func NewAPIKeyHandler(keys *apikey.Service, rotateGrace time.Duration, recorder *audit.Recorder, logger logger.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		keys:        keys,
+		rotateGrace: rotateGrace,
+		audit:       recorder,
+		logger:      logger,
+	}
+}
+
+// APIKeySecretResponse is returned when a secret is issued. The secret is
+// shown only once.
+type APIKeySecretResponse struct {
+	*apikey.APIKey
+	Secret string `json:"secret"`
+} // @name APIKeySecretResponse
+
+// CreateAPIKey godoc
+// @Summary Create API key
+// @Description Issue an API key with the given scopes. The secret is only returned in this response.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body apikey.CreateRequest true "Key settings"
+// @Success 201 {object} APIKeySecretResponse
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/admin/api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req apikey.CreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	createdBy, _ := c.Request.Context().Value(middleware.UserIDKey).(string)
+	key, secret, err := h.keys.Create(c.Request.Context(), req, createdBy)
+	if errors.Is(err, apikey.ErrInvalidRequest) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Errorf("Failed to create API key: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create api key"})
+		return
+	}
+
+	h.logger.Infof("API key %s created by %s", key.ID, createdBy)
+	event := auditEvent(c, "apikey.created", "api_key", key.ID)
+	event.After = key
+	h.audit.Record(event)
+	c.JSON(http.StatusCreated, APIKeySecretResponse{APIKey: key, Secret: secret})
+}
+
+// ListAPIKeys godoc
+// @Summary List API keys
+// @Description List all API keys with their scopes and last use. Secrets are never returned.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} apikey.APIKey
+// @Router /api/v1/admin/api-keys [get]
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	keys, err := h.keys.List(c.Request.Context())
+	if err != nil {
+		h.logger.Errorf("Failed to list API keys: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list api keys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"api_keys": keys})
+}
+
+// RotateAPIKey godoc
+// @Summary Rotate API key
+// @Description Issue a new secret for the key. The previous secret stays valid for the configured grace period.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "API key ID"
+// @Success 200 {object} APIKeySecretResponse
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/admin/api-keys/{id}/rotate [post]
+func (h *APIKeyHandler) RotateAPIKey(c *gin.Context) {
+	key, secret, err := h.keys.Rotate(c.Request.Context(), c.Param("id"), h.rotateGrace)
+	if errors.Is(err, apikey.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "api key not found"})
+		return
+	}
+	if errors.Is(err, apikey.ErrInvalidKey) {
+		c.JSON(http.StatusConflict, gin.H{"error": "revoked or expired keys can't be rotated"})
+		return
+	}
+	if err != nil {
+		h.logger.Errorf("Failed to rotate API key: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to rotate api key"})
+		return
+	}
+
+	h.logger.Infof("API key %s rotated", key.ID)
+	event := auditEvent(c, "apikey.rotated", "api_key", key.ID)
+	event.After = key
+	h.audit.Record(event)
+	c.JSON(http.StatusOK, APIKeySecretResponse{APIKey: key, Secret: secret})
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke API key
+// @Description Revoke the key and any secret still in its rotation grace period
+// @Tags admin
+// @Security ApiKeyAuth
+// @Param id path string true "API key ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/admin/api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	err := h.keys.Revoke(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, apikey.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "api key not found"})
+		return
+	}
+	if err != nil {
+		h.logger.Errorf("Failed to revoke API key: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke api key"})
+		return
+	}
+
+	h.logger.Infof("API key %s revoked", c.Param("id"))
+	h.audit.Record(auditEvent(c, "apikey.revoked", "api_key", c.Param("id")))
+	c.Status(http.StatusNoContent)
+}
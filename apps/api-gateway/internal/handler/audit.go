@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/audit"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
+)
+
+// auditEvent starts the audit event of an action on a resource, taken by the
+// caller of the request
+func auditEvent(c *gin.Context, action, resourceType, resourceID string) audit.Event {
+	actor, _ := c.Request.Context().Value(middleware.UserIDKey).(string)
+	correlationID, _ := c.Request.Context().Value(middleware.RequestIDKey).(string)
+	if correlationID == "" {
+		correlationID = c.GetHeader("X-Request-ID")
+	}
+	return audit.Event{
+		Action:        action,
+		Actor:         actor,
+		ResourceType:  resourceType,
+		ResourceID:    resourceID,
+		IP:            c.ClientIP(),
+		CorrelationID: correlationID,
+	}
+}
+
+// AuditHandler serves the audit log kept by the event bus
+type AuditHandler struct {
+	url    string
+	client *http.Client
+	logger logger.Logger
+}
+
+// NewAuditHandler creates a new audit handler reading the audit log of the
+// event bus at eventBusURL
+func NewAuditHandler(eventBusURL string, timeout time.Duration, logger logger.Logger) *AuditHandler {
+	return &AuditHandler{
+		url:    strings.TrimSuffix(eventBusURL, "/"),
+		client: &http.Client{Timeout: timeout},
+		logger: logger,
+	}
+}
+
+// QueryAuditLog godoc
+// @Summary Query audit log
+// @Description Page of audit entries, newest first: logins, form publishes and deletes, permission changes, admin configuration edits and API key operations. resource is a resource type, or a type and ID as type:id. Pass next_cursor as before for the following page.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param actor query string false "Actor"
+// @Param resource query string false "Resource type, or type:id"
+// @Param from query string false "Earliest occurrence, RFC 3339"
+// @Param to query string false "Occurrences before, RFC 3339"
+// @Param limit query int false "Page size, 50 by default and at most 500"
+// @Param before query string false "next_cursor of the previous page"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 502 {object} map[string]string
+// @Router /api/v1/admin/audit [get]
+func (h *AuditHandler) QueryAuditLog(c *gin.Context) {
+	h.forward(c, "/audit")
+}
+
+// VerifyAuditLog godoc
+// @Summary Verify audit log
+// @Description Walk the hash chain of the audit log and report the first entry that is missing, out of place or altered
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 502 {object} map[string]string
+// @Router /api/v1/admin/audit/verify [get]
+func (h *AuditHandler) VerifyAuditLog(c *gin.Context) {
+	h.forward(c, "/audit/verify")
+}
+
+// forward relays the request to path of the event bus with its query and
+// answers with the event bus response
+func (h *AuditHandler) forward(c *gin.Context, path string) {
+	target := h.url + path
+	if query := c.Request.URL.RawQuery; query != "" {
+		target += "?" + query
+	}
+
+	req, err := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, target, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to query audit log"})
+		return
+	}
+	if requestID := c.GetHeader("X-Request-ID"); requestID != "" {
+		req.Header.Set("X-Request-ID", requestID)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		h.logger.Errorf("Failed to reach the event bus for the audit log: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "audit log unavailable"})
+		return
+	}
+	defer resp.Body.Close()
+
+	c.Header("Content-Type", resp.Header.Get("Content-Type"))
+	c.Status(resp.StatusCode)
+	if _, err := io.Copy(c.Writer, resp.Body); err != nil {
+		h.logger.Warnf("Failed to relay the audit log: %v", err)
+	}
+}
@@ -0,0 +1,279 @@
+package handler
+
+import (
+	"container/list"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultCacheMaxEntries and defaultCacheMaxBody bound the response cache
+// when the configuration sets no limit
+const (
+	defaultCacheMaxEntries = 10000
+	defaultCacheMaxBody    = 1 << 20
+)
+
+// Results of the response cache, as reported in X-Cache and the metrics
+const (
+	cacheHit         = "HIT"
+	cacheRevalidated = "REVALIDATED"
+	cacheMiss        = "MISS"
+)
+
+// responseCache keeps the responses carrying an ETag of the routes with a
+// cache TTL. Fresh entries are served without calling the service; expired
+// ones are revalidated with a conditional request, so an unchanged resource
+// costs the service a 304 rather than its body. Clients holding the ETag get
+// a 304 either way. Writes through the gateway purge the entries of the
+// resource written and its ancestors.
+type responseCache struct {
+	maxEntries int
+	maxBody    int64
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// byPath indexes the keys of the entries by the path they were fetched
+	// from, for purging
+	byPath map[string]map[string]struct{}
+	// lru holds the entries, the most recently used first
+	lru *list.List
+}
+
+// cacheEntry is a response kept in the cache
+type cacheEntry struct {
+	key      string
+	path     string
+	response *recordedResponse
+	expires  time.Time
+}
+
+func newResponseCache(maxEntries int, maxBody int64) *responseCache {
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheMaxEntries
+	}
+	if maxBody <= 0 {
+		maxBody = defaultCacheMaxBody
+	}
+	return &responseCache{
+		maxEntries: maxEntries,
+		maxBody:    maxBody,
+		now:        time.Now,
+		entries:    make(map[string]*list.Element),
+		byPath:     make(map[string]map[string]struct{}),
+		lru:        list.New(),
+	}
+}
+
+// serve answers the GET r from the entry of key, revalidating it when it
+// expired, or fetches the resource when there is none. fetch calls the
+// service; the request passed to it carries no conditional headers of the
+// client, only the ETag of the entry being revalidated. It returns the
+// result of the cache.
+func (c *responseCache) serve(w http.ResponseWriter, r *http.Request, key string, ttl time.Duration, fetch http.HandlerFunc) string {
+	entry, fresh := c.get(key)
+	if fresh {
+		c.respond(w, r, entry.response, cacheHit)
+		return cacheHit
+	}
+
+	upstream := r.Clone(r.Context())
+	upstream.Header.Del("If-None-Match")
+	upstream.Header.Del("If-Modified-Since")
+	if entry != nil {
+		upstream.Header.Set("If-None-Match", entry.response.header.Get("ETag"))
+	}
+
+	recorder := &coalesceRecorder{w: w, header: make(http.Header), max: c.maxBody}
+	fetch(recorder, upstream)
+	if recorder.overflowed {
+		// The body already streamed to the client and is too large to keep
+		c.remove(key)
+		return cacheMiss
+	}
+	response := recorder.response()
+
+	if entry != nil && response.status == http.StatusNotModified {
+		// The service confirmed the entry, and sent its up to date headers
+		refreshed := &recordedResponse{status: entry.response.status, header: entry.response.header.Clone(), body: entry.response.body}
+		for _, name := range notModifiedHeaders {
+			if values := response.header.Values(name); len(values) > 0 {
+				refreshed.header[http.CanonicalHeaderKey(name)] = values
+			}
+		}
+		c.put(key, r.URL.Path, refreshed, ttl)
+		c.respond(w, r, refreshed, cacheRevalidated)
+		return cacheRevalidated
+	}
+
+	if cacheable(response) {
+		c.put(key, r.URL.Path, response, ttl)
+		c.respond(w, r, response, cacheMiss)
+		return cacheMiss
+	}
+	c.remove(key)
+	response.header.Set("X-Cache", cacheMiss)
+	response.writeTo(w)
+	return cacheMiss
+}
+
+// notModifiedHeaders are the headers a 304 carries, as RFC 9110 lists them
+var notModifiedHeaders = []string{"Cache-Control", "Content-Location", "Date", "ETag", "Expires", "Vary"}
+
+// respond writes the cached response, or a 304 when the client already holds
+// it
+func (c *responseCache) respond(w http.ResponseWriter, r *http.Request, response *recordedResponse, result string) {
+	if etagMatches(r.Header.Get("If-None-Match"), response.header.Get("ETag")) {
+		for _, name := range notModifiedHeaders {
+			if values := response.header.Values(name); len(values) > 0 {
+				setHeader(w.Header(), name, values)
+			}
+		}
+		w.Header().Set("X-Cache", result)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("X-Cache", result)
+	response.writeTo(w)
+}
+
+// cacheable reports whether the response may be kept: a 200 with an ETag
+// that sets no cookie and does not forbid storing it
+func cacheable(response *recordedResponse) bool {
+	return response.status == http.StatusOK && response.header.Get("ETag") != "" &&
+		response.header.Get("Set-Cookie") == "" &&
+		!strings.Contains(strings.ToLower(response.header.Get("Cache-Control")), "no-store")
+}
+
+// etagMatches reports whether the If-None-Match header lists etag, compared
+// weakly as RFC 9110 requires
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" || etag == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// get returns the entry of key and whether it is still fresh
+func (c *responseCache) get(key string) (*cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(element)
+	entry := element.Value.(*cacheEntry)
+	return entry, c.now().Before(entry.expires)
+}
+
+// put stores the response of key for ttl, evicting the least recently used
+// entry when the cache is full. Responses the service marks no-cache are
+// kept but revalidated on every use.
+func (c *responseCache) put(key, path string, response *recordedResponse, ttl time.Duration) {
+	if strings.Contains(strings.ToLower(response.header.Get("Cache-Control")), "no-cache") {
+		ttl = 0
+	}
+	entry := &cacheEntry{key: key, path: path, response: response, expires: c.now().Add(ttl)}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.removeElement(element)
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	keys := c.byPath[path]
+	if keys == nil {
+		keys = make(map[string]struct{})
+		c.byPath[path] = keys
+	}
+	keys[key] = struct{}{}
+
+	for c.lru.Len() > c.maxEntries {
+		c.removeElement(c.lru.Back())
+	}
+}
+
+func (c *responseCache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.removeElement(element)
+	}
+}
+
+// purge drops the entries of path and of its ancestors, for every caller, as
+// a write to a resource changes the views of the resources holding it. It
+// returns the number of entries dropped.
+func (c *responseCache) purge(path string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	purged := 0
+	for path = strings.TrimSuffix(path, "/"); path != ""; path = path[:strings.LastIndex(path, "/")] {
+		for key := range c.byPath[path] {
+			c.removeElement(c.entries[key])
+			purged++
+		}
+	}
+	return purged
+}
+
+// purgeTree drops the entries of path and of its descendants, besides those
+// of its ancestors as purge does, as every view below a resource shows it.
+// It returns the number of entries dropped.
+func (c *responseCache) purgeTree(path string) int {
+	purged := c.purge(path)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	prefix := strings.TrimSuffix(path, "/") + "/"
+	for descendant, keys := range c.byPath {
+		if !strings.HasPrefix(descendant, prefix) {
+			continue
+		}
+		for key := range keys {
+			c.removeElement(c.entries[key])
+			purged++
+		}
+	}
+	return purged
+}
+
+// purgeMatching drops the entries of the paths matching, for every caller.
+// It returns the number of entries dropped.
+func (c *responseCache) purgeMatching(match func(path string) bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	purged := 0
+	for path, keys := range c.byPath {
+		if !match(path) {
+			continue
+		}
+		for key := range keys {
+			c.removeElement(c.entries[key])
+			purged++
+		}
+	}
+	return purged
+}
+
+// removeElement drops an entry; c.mu must be held
+func (c *responseCache) removeElement(element *list.Element) {
+	entry := c.lru.Remove(element).(*cacheEntry)
+	delete(c.entries, entry.key)
+	if keys := c.byPath[entry.path]; keys != nil {
+		delete(keys, entry.key)
+		if len(keys) == 0 {
+			delete(c.byPath, entry.path)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/apikey"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/audit"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
+)
+
+// Scopes of the caches admins clear
+const (
+	cacheScopeResponses  = "responses"
+	cacheScopeAPIKeys    = "api_keys"
+	cacheScopeRegistry   = "registry"
+	cacheScopeRateLimits = "rate_limits"
+	cacheScopeForms      = "forms"
+)
+
+// cachePurger drops the entries of a cache whose key matches a pattern and
+// returns the number dropped
+type cachePurger func(ctx context.Context, pattern string) (int, error)
+
+// ClearCacheRequest selects the caches to clear and, with Pattern, the
+// entries to clear in them
+type ClearCacheRequest struct {
+	Scopes []string `json:"scopes" binding:"required,min=1" example:"responses,registry"`
+	// Pattern matches the keys of the entries cleared: * matches any run of
+	// characters and ? any one. Every entry is cleared without it.
+	Pattern string `json:"pattern" binding:"max=200" example:"/api/v1/forms/*"`
+	// Confirm must be set to clear rate_limits
+	Confirm bool `json:"confirm"`
+} // @name ClearCacheRequest
+
+// CacheClearResult is the outcome of clearing a cache
+type CacheClearResult struct {
+	Purged int    `json:"purged"`
+	Error  string `json:"error,omitempty"`
+} // @name CacheClearResult
+
+// ClearCacheResponse reports the outcome of clearing each scope requested
+type ClearCacheResponse struct {
+	Pattern string                      `json:"pattern"`
+	Results map[string]CacheClearResult `json:"results"`
+} // @name ClearCacheResponse
+
+// CacheAdminHandler clears the caches of the gateway and of the form service
+// on behalf of admins. Clears are throttled, as each one sends the requests
+// the caches absorbed to the services until they fill again.
+type CacheAdminHandler struct {
+	purgers  map[string]cachePurger
+	interval time.Duration
+	audit    *audit.Recorder
+	logger   logger.Logger
+	now      func() time.Time
+
+	mu        sync.Mutex
+	lastClear time.Time
+}
+
+// NewCacheAdminHandler creates a new cache admin handler clearing the
+// response cache of gateway, the keys cached by apiKeys, the health verdicts
+// of registry, the rate limit counters in rateLimits and, through its
+// internal API, the caches of the form service at formServiceURL,
+// authenticated by serviceToken. rateLimits is nil when the counters are not kept in Redis. A replica
+// clears caches at most once per interval. Clears are recorded to recorder.
+func NewCacheAdminHandler(gateway *Handler, apiKeys *apikey.Service, registry *middleware.ServiceRegistry, rateLimits *redis.Client, formServiceURL, serviceToken string, timeout, interval time.Duration, recorder *audit.Recorder, logger logger.Logger) *CacheAdminHandler {
+	client := &http.Client{Timeout: timeout}
+	formServiceURL = strings.TrimSuffix(formServiceURL, "/")
+
+	return &CacheAdminHandler{
+		purgers: map[string]cachePurger{
+			cacheScopeResponses: func(ctx context.Context, pattern string) (int, error) {
+				if gateway.cache == nil {
+					return 0, nil
+				}
+				return gateway.cache.purgeMatching(globMatcher(pattern)), nil
+			},
+			cacheScopeAPIKeys: func(ctx context.Context, pattern string) (int, error) {
+				return apiKeys.PurgeCache(globMatcher(pattern)), nil
+			},
+			cacheScopeRegistry: func(ctx context.Context, pattern string) (int, error) {
+				return registry.ResetHealth(globMatcher(pattern)), nil
+			},
+			cacheScopeRateLimits: func(ctx context.Context, pattern string) (int, error) {
+				if rateLimits == nil {
+					return 0, fmt.Errorf("rate limits are not counted in Redis")
+				}
+				return middleware.PurgeRateLimits(ctx, rateLimits, pattern)
+			},
+			cacheScopeForms: func(ctx context.Context, pattern string) (int, error) {
+				return purgeFormServiceCaches(ctx, client, formServiceURL, serviceToken, pattern)
+			},
+		},
+		interval: interval,
+		audit:    recorder,
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+// ClearCache godoc
+// @Summary Clear caches
+// @Description Clear the entries of the caches selected by scopes whose key matches pattern, every entry without one. responses is the response cache of this replica, keyed by path; api_keys the API keys it validated, keyed by key ID; registry the health of the service instances it routes to, keyed by service name, which are checked again at once; forms the caches of the form service, keyed by form ID. rate_limits resets the rate limit counters of every replica, keyed by scope and client as tier:name:client or global:client, and requires confirm. The purged entries and the failure of each scope are reported; the response is 502 when a scope failed. A replica clears caches at most once per cache_clear.min_interval.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body ClearCacheRequest true "Caches to clear"
+// @Success 200 {object} ClearCacheResponse
+// @Failure 400 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 502 {object} ClearCacheResponse
+// @Router /api/v1/admin/cache/clear [post]
+func (h *CacheAdminHandler) ClearCache(c *gin.Context) {
+	var req ClearCacheRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if strings.ContainsAny(req.Pattern, `[]\`) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "pattern may only use * and ? as wildcards"})
+		return
+	}
+	if req.Pattern == "" {
+		req.Pattern = "*"
+	}
+
+	scopes := make([]string, 0, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		if _, ok := h.purgers[scope]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown cache scope %q, want one of %s", scope, strings.Join(h.scopes(), ", "))})
+			return
+		}
+		if scope == cacheScopeRateLimits && !req.Confirm {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "clearing rate_limits lets the clients matched send a full window of requests again; set confirm to clear them"})
+			return
+		}
+		if !containsString(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	if wait := h.admit(); wait > 0 {
+		c.Header("Retry-After", strconv.Itoa(int((wait+time.Second-1)/time.Second)))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "caches were cleared recently; retry later"})
+		return
+	}
+
+	results := make(map[string]CacheClearResult, len(scopes))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, scope := range scopes {
+		wg.Add(1)
+		go func(scope string) {
+			defer wg.Done()
+			purged, err := h.purgers[scope](c.Request.Context(), req.Pattern)
+			result := CacheClearResult{Purged: purged}
+			if err != nil {
+				h.logger.Errorf("Failed to clear the %s cache: %v", scope, err)
+				result.Error = err.Error()
+			}
+			mu.Lock()
+			results[scope] = result
+			mu.Unlock()
+		}(scope)
+	}
+	wg.Wait()
+
+	response := ClearCacheResponse{Pattern: req.Pattern, Results: results}
+	event := auditEvent(c, "cache.cleared", "cache", strings.Join(scopes, ","))
+	event.After = response
+	h.audit.Record(event)
+
+	status := http.StatusOK
+	for _, result := range results {
+		if result.Error != "" {
+			status = http.StatusBadGateway
+		}
+	}
+	c.JSON(status, response)
+}
+
+// admit starts a clear unless the last one started within the interval, and
+// returns how long to wait otherwise
+func (h *CacheAdminHandler) admit() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := h.now()
+	if wait := h.lastClear.Add(h.interval).Sub(now); !h.lastClear.IsZero() && wait > 0 {
+		return wait
+	}
+	h.lastClear = now
+	return 0
+}
+
+// scopes lists the cache scopes known, sorted
+func (h *CacheAdminHandler) scopes() []string {
+	scopes := make([]string, 0, len(h.purgers))
+	for scope := range h.purgers {
+		scopes = append(scopes, scope)
+	}
+	sort.Strings(scopes)
+	return scopes
+}
+
+// purgeFormServiceCaches clears the caches of the form service through its
+// internal API, and returns the number of entries it purged over all of them
+func purgeFormServiceCaches(ctx context.Context, client *http.Client, formServiceURL, serviceToken, pattern string) (int, error) {
+	body, err := json.Marshal(map[string]string{"pattern": pattern})
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, formServiceURL+"/internal/cache/purge", bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	setServiceToken(req, serviceToken)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("form service unavailable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return 0, fmt.Errorf("form service answered %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	var purged struct {
+		Purged map[string]int `json:"purged"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&purged); err != nil {
+		return 0, fmt.Errorf("failed to read the form service response: %w", err)
+	}
+	total := 0
+	for _, n := range purged.Purged {
+		total += n
+	}
+	return total, nil
+}
+
+// globMatcher matches keys against pattern, where * matches any run of
+// characters and ? any one, as Redis MATCH does
+func globMatcher(pattern string) func(string) bool {
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.ReplaceAll(expr, `\*`, ".*")
+	expr = strings.ReplaceAll(expr, `\?`, ".")
+	re := regexp.MustCompile("^" + expr + "$")
+	return re.MatchString
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
+)
+
+func clearCache(h *CacheAdminHandler, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/cache/clear", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	c.Request = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "admin-1"))
+	h.ClearCache(c)
+	return w
+}
+
+func TestClearCacheFansOut(t *testing.T) {
+	var pattern string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/internal/cache/purge" {
+			t.Errorf("path = %s, want /internal/cache/purge", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer service-token" {
+			t.Errorf("Authorization = %q, want the service token", got)
+		}
+		var req struct{ Pattern string }
+		json.NewDecoder(r.Body).Decode(&req)
+		pattern = req.Pattern
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"purged":{"public_results":3}}`))
+	}))
+	defer server.Close()
+
+	gateway, _, _ := newCachingHandler(t)
+	response := &recordedResponse{status: http.StatusOK, header: http.Header{"Etag": {`"v1"`}}}
+	gateway.cache.put("form", "/api/v1/forms/form-1", response, time.Minute)
+	gateway.cache.put("questions", "/api/v1/forms/form-1/questions", response, time.Minute)
+	gateway.cache.put("public", "/api/v1/public/forms/form-1", response, time.Minute)
+
+	log := logger.New(logger.LogConfig{Level: "fatal", Format: "json", Output: "stderr"})
+	h := NewCacheAdminHandler(gateway, nil, nil, nil, server.URL, "service-token", time.Second, time.Minute, nil, log)
+
+	w := clearCache(h, `{"scopes":["responses","forms","responses"],"pattern":"/api/v1/forms/*"}`)
+	var got ClearCacheResponse
+	json.Unmarshal(w.Body.Bytes(), &got)
+	if w.Code != http.StatusOK || len(got.Results) != 2 {
+		t.Fatalf("POST = %d %s, want 200 with responses and forms", w.Code, w.Body.String())
+	}
+	if got.Results[cacheScopeResponses].Purged != 2 || got.Results[cacheScopeForms].Purged != 3 {
+		t.Errorf("results = %+v, want 2 responses and 3 form service entries purged", got.Results)
+	}
+	if _, ok := gateway.cache.entries["public"]; !ok {
+		t.Error("the public form was purged, want it kept as it does not match")
+	}
+	if pattern != "/api/v1/forms/*" {
+		t.Errorf("form service got pattern %q, want the pattern requested", pattern)
+	}
+
+	// A second clear within the interval is refused
+	w = clearCache(h, `{"scopes":["responses"]}`)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("second POST = %d %v, want 429 with Retry-After", w.Code, w.Header())
+	}
+}
+
+func TestClearCacheReportsFailures(t *testing.T) {
+	gateway, _, _ := newCachingHandler(t)
+	log := logger.New(logger.LogConfig{Level: "fatal", Format: "json", Output: "stderr"})
+	h := NewCacheAdminHandler(gateway, nil, nil, nil, "http://127.0.0.1:1", "service-token", time.Second, time.Minute, nil, log)
+
+	w := clearCache(h, `{"scopes":["responses","forms"]}`)
+	var got ClearCacheResponse
+	json.Unmarshal(w.Body.Bytes(), &got)
+	if w.Code != http.StatusBadGateway || got.Results[cacheScopeForms].Error == "" || got.Results[cacheScopeResponses].Error != "" {
+		t.Errorf("POST = %d %s, want 502 with the forms scope failed alone", w.Code, w.Body.String())
+	}
+}
+
+func TestClearCacheRejects(t *testing.T) {
+	gateway, _, _ := newCachingHandler(t)
+	log := logger.New(logger.LogConfig{Level: "fatal", Format: "json", Output: "stderr"})
+	h := NewCacheAdminHandler(gateway, nil, nil, nil, "http://127.0.0.1:1", "service-token", time.Second, time.Minute, nil, log)
+
+	for name, body := range map[string]string{
+		"no scopes":                  `{"scopes":[]}`,
+		"unknown scope":              `{"scopes":["jwks"]}`,
+		"unconfirmed rate limits":    `{"scopes":["rate_limits"]}`,
+		"character class in pattern": `{"scopes":["responses"],"pattern":"/api/v1/forms/[a-f]*"}`,
+	} {
+		if w := clearCache(h, body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: POST = %d, want 400", name, w.Code)
+		}
+	}
+	// Rejected requests clear nothing, so they do not count against the interval
+	if w := clearCache(h, `{"scopes":["responses"]}`); w.Code != http.StatusOK {
+		t.Errorf("POST after the rejections = %d, want 200", w.Code)
+	}
+}
+
+func TestGlobMatcher(t *testing.T) {
+	match := globMatcher("/api/v1/forms/?/*")
+	for key, want := range map[string]bool{
+		"/api/v1/forms/a/questions":  true,
+		"/api/v1/forms/a/b/c":        true,
+		"/api/v1/forms/ab/questions": false,
+		"/api/v1/forms.a/x":          false,
+		"/api/v1/public/forms/a/x":   false,
+	} {
+		if got := match(key); got != want {
+			t.Errorf("match(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/config"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/policy"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/routes"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/metrics"
+)
+
+// versionedForm is a form service honoring If-None-Match on a form whose
+// ETag is its version, recording what it sends
+type versionedForm struct {
+	mu        sync.Mutex
+	version   int
+	bodies    int
+	notModify int
+	users     []string
+}
+
+func (f *versionedForm) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Method != http.MethodGet {
+		// Publishing makes a new version
+		f.version++
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	f.users = append(f.users, r.Header.Get("X-User-ID"))
+	etag := fmt.Sprintf(`"v%d"`, f.version)
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		f.notModify++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	f.bodies++
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"id":"form-1","version":%d}`, f.version)
+}
+
+func (f *versionedForm) sent() (bodies, notModified int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.bodies, f.notModify
+}
+
+func newCachingHandler(t *testing.T) (*Handler, *versionedForm, *time.Time) {
+	t.Helper()
+	form := &versionedForm{version: 1}
+	server := httptest.NewServer(form)
+	t.Cleanup(server.Close)
+
+	cfg := &config.Config{Proxy: config.ProxyConfig{Coalesce: true, Cache: true}}
+	log := logger.New(logger.LogConfig{Level: "fatal", Format: "json", Output: "stderr"})
+	h := NewHandler(cfg, log, metrics.NewCollector(metrics.Config{Enabled: true}), nil)
+	now := time.Now()
+	h.cache.now = func() time.Time { return now }
+
+	service := h.services["form-service"]
+	service.BaseURL = server.URL
+	h.proxies["form-service"] = h.createReverseProxy(service)
+	return h, form, &now
+}
+
+var cachedForm = policy.Policy{Pattern: "/forms/:id", CacheTTL: 30 * time.Second}
+
+func cachedRequest(method, userID, ifNoneMatch string) *http.Request {
+	r := httptest.NewRequest(method, "/api/v1/forms/form-1", nil)
+	ctx := policy.NewContext(r.Context(), cachedForm)
+	if userID != "" {
+		ctx = context.WithValue(ctx, middleware.UserIDKey, userID)
+	}
+	if ifNoneMatch != "" {
+		r.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	return r.WithContext(ctx)
+}
+
+func TestCacheConditionalRoundTrip(t *testing.T) {
+	h, form, now := newCachingHandler(t)
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ProxyRoute(w, cachedRequest(http.MethodGet, "user-1", ifNoneMatch), "form-service", routes.AuthOptional)
+		return w
+	}
+
+	first := get("")
+	if first.Code != http.StatusOK || first.Header().Get("ETag") != `"v1"` || first.Header().Get("X-Cache") != cacheMiss {
+		t.Fatalf("first GET = %d %v, want 200 with the ETag", first.Code, first.Header())
+	}
+	if vary := first.Header().Values("Vary"); len(vary) == 0 || vary[0] != "Authorization" {
+		t.Errorf("Vary = %v, want Authorization", vary)
+	}
+
+	// A fresh entry answers the client holding the ETag without the service
+	w := get(`"v1"`)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 || w.Header().Get("X-Cache") != cacheHit {
+		t.Errorf("GET with the ETag = %d %q %v, want 304 from the cache", w.Code, w.Body, w.Header())
+	}
+
+	// Once expired, the entry is revalidated and the service sends no body
+	*now = now.Add(time.Minute)
+	w = get(`"v1"`)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 || w.Header().Get("ETag") != `"v1"` ||
+		w.Header().Get("X-Cache") != cacheRevalidated {
+		t.Errorf("GET after expiry = %d %q %v, want a revalidated 304", w.Code, w.Body, w.Header())
+	}
+	if bodies, notModified := form.sent(); bodies != 1 || notModified != 1 {
+		t.Errorf("service sent %d bodies and %d 304s, want 1 body and 1 304", bodies, notModified)
+	}
+
+	// The revalidated entry is fresh again and still serves the body
+	w = get("")
+	if w.Code != http.StatusOK || w.Body.String() != `{"id":"form-1","version":1}` || w.Header().Get("X-Cache") != cacheHit {
+		t.Errorf("GET without an ETag = %d %q, want the cached body", w.Code, w.Body)
+	}
+	if got := testutil.ToFloat64(h.metrics.CachedResponses.WithLabelValues("form-service", cacheHit)); got != 2 {
+		t.Errorf("cached_responses_total{result=HIT} = %v, want 2", got)
+	}
+}
+
+func TestCachePublishChangesETag(t *testing.T) {
+	h, form, _ := newCachingHandler(t)
+	get := func(userID, ifNoneMatch string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ProxyRoute(w, cachedRequest(http.MethodGet, userID, ifNoneMatch), "form-service", routes.AuthOptional)
+		return w
+	}
+	get("user-1", "")
+	get("user-2", "")
+
+	// Publishing through the gateway purges every caller's entry, although
+	// they are still fresh
+	publish := httptest.NewRequest(http.MethodPost, "/api/v1/forms/form-1/publish", nil)
+	h.ProxyRoute(httptest.NewRecorder(), publish, "form-service", routes.AuthRequired)
+
+	for _, userID := range []string{"user-1", "user-2"} {
+		w := get(userID, `"v1"`)
+		if w.Code != http.StatusOK || w.Header().Get("ETag") != `"v2"` || w.Body.String() != `{"id":"form-1","version":2}` {
+			t.Errorf("GET of %s after publishing = %d %v %q, want the new version", userID, w.Code, w.Header(), w.Body)
+		}
+	}
+	if bodies, _ := form.sent(); bodies != 4 {
+		t.Errorf("service sent %d bodies, want 4", bodies)
+	}
+}
+
+func TestCacheKeepsUsersApart(t *testing.T) {
+	h, form, _ := newCachingHandler(t)
+	for _, userID := range []string{"user-1", "user-2", "user-1", "user-2", ""} {
+		w := httptest.NewRecorder()
+		h.ProxyRoute(w, cachedRequest(http.MethodGet, userID, ""), "form-service", routes.AuthOptional)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET of %q = %d, want 200", userID, w.Code)
+		}
+	}
+	if bodies, _ := form.sent(); bodies != 3 {
+		t.Errorf("service sent %d bodies, want 3: one per user and one anonymous", bodies)
+	}
+
+	// Public routes share one entry between every caller
+	h, form, _ = newCachingHandler(t)
+	for _, userID := range []string{"user-1", "user-2", ""} {
+		w := httptest.NewRecorder()
+		h.ProxyRoute(w, cachedRequest(http.MethodGet, userID, ""), "form-service", routes.AuthPublic)
+		if w.Header().Get("Vary") != "" {
+			t.Errorf("Vary on a public route = %q, want none", w.Header().Get("Vary"))
+		}
+	}
+	if bodies, _ := form.sent(); bodies != 1 {
+		t.Errorf("service sent %d bodies on a public route, want 1", bodies)
+	}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newResponseCache(2, 0)
+	response := &recordedResponse{status: http.StatusOK, header: http.Header{"Etag": {`"v1"`}}}
+	c.put("a", "/forms/a", response, time.Minute)
+	c.put("b", "/forms/b", response, time.Minute)
+	c.get("a")
+	c.put("c", "/forms/c", response, time.Minute)
+
+	if _, ok := c.entries["b"]; ok {
+		t.Error("b kept, want it evicted as the least recently used")
+	}
+	if _, ok := c.entries["a"]; !ok {
+		t.Error("a evicted, want it kept")
+	}
+	if purged := c.purge("/forms/a/questions/q1"); purged != 1 || len(c.byPath) != 1 {
+		t.Errorf("purge = %d leaving %d paths, want the ancestor purged", purged, len(c.byPath))
+	}
+}
+
+func TestCacheInvalidationPurgesFormViews(t *testing.T) {
+	h, form, now := newCachingHandler(t)
+	invalidator := h.NewCacheInvalidator(100 * time.Millisecond)
+	invalidator.now = func() time.Time { return *now }
+
+	response := &recordedResponse{status: http.StatusOK, header: http.Header{"Etag": {`"v1"`}}}
+	h.cache.put("definition", "/api/v1/public/forms/form-1/definition", response, time.Minute)
+	h.cache.put("slug", "/api/v1/public/forms/by-slug/survey", response, time.Minute)
+	h.cache.put("old-slug", "/api/v1/public/forms/by-slug/old-survey", response, time.Minute)
+	h.cache.put("analytics", "/api/v1/analytics/forms/form-1/summary", response, time.Minute)
+	h.cache.put("other", "/api/v1/forms/form-2", response, time.Minute)
+	h.ProxyRoute(httptest.NewRecorder(), cachedRequest(http.MethodGet, "user-1", ""), "form-service", routes.AuthOptional)
+
+	// The form changed through another replica
+	form.mu.Lock()
+	form.version++
+	form.mu.Unlock()
+
+	changed := now.Add(-2 * time.Second)
+	for _, payload := range []string{
+		fmt.Sprintf(`{"form_id":"form-1","slugs":["survey"],"event_types":["form.updated"],"at":%q}`, changed.Add(time.Second).Format(time.RFC3339Nano)),
+		fmt.Sprintf(`{"form_id":"form-1","slugs":["old-survey"],"event_types":["form.updated"],"at":%q}`, changed.Format(time.RFC3339Nano)),
+		`{"slugs":["survey"]}`,
+	} {
+		invalidator.receive(payload)
+	}
+	invalidator.flush()
+
+	if len(h.cache.entries) != 1 {
+		t.Errorf("cache kept %d entries, want only the other form", len(h.cache.entries))
+	}
+	if _, ok := h.cache.entries["other"]; !ok {
+		t.Error("the other form was purged, want it kept")
+	}
+	w := httptest.NewRecorder()
+	h.ProxyRoute(w, cachedRequest(http.MethodGet, "user-1", ""), "form-service", routes.AuthOptional)
+	if w.Header().Get("ETag") != `"v2"` || w.Header().Get("X-Cache") != cacheMiss {
+		t.Errorf("GET after the invalidation = %v, want the new version from the service", w.Header())
+	}
+
+	for result, want := range map[string]float64{invalidationPurged: 1, invalidationCoalesced: 1, invalidationInvalid: 1} {
+		if got := testutil.ToFloat64(h.metrics.CacheInvalidations.WithLabelValues(result)); got != want {
+			t.Errorf("cache_invalidations_total{result=%s} = %v, want %v", result, got, want)
+		}
+	}
+}
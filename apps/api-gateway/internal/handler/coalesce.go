@@ -0,0 +1,223 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/routes"
+)
+
+// defaultCoalesceMaxBody caps the response shared by coalesced requests when
+// the configuration sets no limit
+const defaultCoalesceMaxBody = 1 << 20
+
+// coalescer lets identical concurrent GETs share one upstream call, so an
+// expired cache entry of a popular form does not send every waiting request
+// to the service
+type coalescer struct {
+	maxBody int64
+
+	mu    sync.Mutex
+	calls map[string]*coalescedCall
+}
+
+// coalescedCall is an upstream call in flight and the requests waiting for it
+type coalescedCall struct {
+	done chan struct{}
+	// waiters counts the requests waiting for the call
+	waiters int
+	// response is nil when it cannot be shared, and the waiting requests
+	// call the service themselves
+	response *recordedResponse
+}
+
+// recordedResponse is a response replayed to every coalesced request
+type recordedResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+func newCoalescer(maxBody int64) *coalescer {
+	if maxBody <= 0 {
+		maxBody = defaultCoalesceMaxBody
+	}
+	return &coalescer{maxBody: maxBody, calls: make(map[string]*coalescedCall)}
+}
+
+// coalesceKey returns the key shared by the requests that may receive the
+// same response, or false when the request must reach the service itself
+func coalesceKey(r *http.Request, auth routes.Auth) (string, bool) {
+	key, ok := resourceKey(r, auth)
+	if !ok {
+		return "", false
+	}
+	// A conditional request may be answered 304, which an unconditional one
+	// must not receive
+	return key + "\x00" + r.Header.Get("If-None-Match") + "\x00" + r.Header.Get("If-Modified-Since"), true
+}
+
+// resourceKey returns the key shared by the requests for the same
+// representation of a resource, whatever copy they hold, or false when the
+// request must reach the service itself. Only GETs qualify. Responses of
+// public routes are shared by every caller; on other routes only by requests
+// of the same user, or anonymous requests. Requests of different respondent
+// tokens are kept apart, as public form definitions carry the shuffle seed of
+// the respondent.
+func resourceKey(r *http.Request, auth routes.Auth) (string, bool) {
+	if r.Method != http.MethodGet || r.Header.Get("Upgrade") != "" || r.Header.Get("Range") != "" ||
+		strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		return "", false
+	}
+
+	scope := "public"
+	if auth != routes.AuthPublic {
+		userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+		switch {
+		case userID != "":
+			scope = "user:" + userID
+		case r.Header.Get("Authorization") != "" || r.Header.Get("Cookie") != "":
+			// Credentials the gateway did not resolve to a user may still
+			// make the response user-specific
+			return "", false
+		default:
+			scope = "anonymous"
+		}
+	}
+
+	// Query parameters in any order name the same resource
+	return strings.Join([]string{
+		r.Method,
+		r.URL.Path,
+		r.URL.Query().Encode(),
+		scope,
+		r.Header.Get("Accept"),
+		r.Header.Get("Accept-Encoding"),
+		r.Header.Get("X-Respondent-Token"),
+	}, "\x00"), true
+}
+
+// do serves the request with the response of the identical call in flight,
+// or makes the call with proxy when there is none. It reports whether the
+// request was served from another request's call.
+func (c *coalescer) do(w http.ResponseWriter, r *http.Request, key string, proxy http.HandlerFunc) bool {
+	c.mu.Lock()
+	if call, ok := c.calls[key]; ok {
+		call.waiters++
+		c.mu.Unlock()
+		select {
+		case <-call.done:
+		case <-r.Context().Done():
+			return false
+		}
+		if call.response == nil {
+			proxy(w, r)
+			return false
+		}
+		call.response.writeTo(w)
+		return true
+	}
+	call := &coalescedCall{done: make(chan struct{})}
+	c.calls[key] = call
+	c.mu.Unlock()
+
+	// The call outlives the caller that made it, as others wait for it
+	recorder := &coalesceRecorder{w: w, header: make(http.Header), max: c.maxBody}
+	defer func() {
+		c.mu.Lock()
+		delete(c.calls, key)
+		c.mu.Unlock()
+		close(call.done)
+	}()
+	proxy(recorder, r.WithContext(context.WithoutCancel(r.Context())))
+
+	if recorder.overflowed {
+		return false
+	}
+	response := recorder.response()
+	response.writeTo(w)
+	// Cookies are never handed to other callers
+	if response.header.Get("Set-Cookie") == "" {
+		call.response = response
+	}
+	return false
+}
+
+// writeTo replays the response, keeping the headers already set on w. Vary
+// adds to the fields already listed.
+func (rr *recordedResponse) writeTo(w http.ResponseWriter) {
+	for key, values := range rr.header {
+		setHeader(w.Header(), key, values)
+	}
+	w.WriteHeader(rr.status)
+	w.Write(rr.body)
+}
+
+// setHeader sets the values of the header name, adding them to those of Vary
+func setHeader(header http.Header, name string, values []string) {
+	name = http.CanonicalHeaderKey(name)
+	if name == "Vary" {
+		header[name] = append(header[name], values...)
+		return
+	}
+	header[name] = append([]string(nil), values...)
+}
+
+// coalesceRecorder records the response of a coalesced call. A body
+// outgrowing max is streamed to w instead and not shared.
+type coalesceRecorder struct {
+	w      http.ResponseWriter
+	header http.Header
+	status int
+	body   bytes.Buffer
+	max    int64
+
+	overflowed bool
+}
+
+func (cr *coalesceRecorder) Header() http.Header {
+	if cr.overflowed {
+		return cr.w.Header()
+	}
+	return cr.header
+}
+
+func (cr *coalesceRecorder) WriteHeader(status int) {
+	if cr.status == 0 {
+		cr.status = status
+	}
+}
+
+func (cr *coalesceRecorder) Write(p []byte) (int, error) {
+	if cr.status == 0 {
+		cr.WriteHeader(http.StatusOK)
+	}
+	if cr.overflowed {
+		return cr.w.Write(p)
+	}
+	if int64(cr.body.Len()+len(p)) > cr.max {
+		cr.overflowed = true
+		cr.response().writeTo(cr.w)
+		return cr.w.Write(p)
+	}
+	return cr.body.Write(p)
+}
+
+// Flush is a no-op until the response streams to the caller
+func (cr *coalesceRecorder) Flush() {
+	if flusher, ok := cr.w.(http.Flusher); ok && cr.overflowed {
+		flusher.Flush()
+	}
+}
+
+func (cr *coalesceRecorder) response() *recordedResponse {
+	status := cr.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return &recordedResponse{status: status, header: cr.header.Clone(), body: cr.body.Bytes()}
+}
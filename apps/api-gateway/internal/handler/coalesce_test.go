@@ -0,0 +1,246 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/config"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/routes"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/metrics"
+)
+
+// upstream is a form service counting its hits, holding every response
+// until released
+type upstream struct {
+	hits    atomic.Int32
+	release chan struct{}
+	body    string
+}
+
+func (u *upstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	hit := u.hits.Add(1)
+	<-u.release
+	w.Header().Set("X-Upstream-Hit", fmt.Sprint(hit))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, u.body)
+}
+
+func newCoalescingHandler(t *testing.T, maxBody int64) (*Handler, *upstream) {
+	t.Helper()
+	up := &upstream{release: make(chan struct{}), body: `{"id":"form-1","title":"Popular form"}`}
+	server := httptest.NewServer(up)
+	t.Cleanup(server.Close)
+	t.Cleanup(func() {
+		select {
+		case <-up.release:
+		default:
+			close(up.release)
+		}
+	})
+
+	cfg := &config.Config{Proxy: config.ProxyConfig{Coalesce: true, CoalesceMaxBodyBytes: maxBody}}
+	log := logger.New(logger.LogConfig{Level: "fatal", Format: "json", Output: "stderr"})
+	h := NewHandler(cfg, log, metrics.NewCollector(metrics.Config{Enabled: true}), nil)
+
+	service := h.services["form-service"]
+	service.BaseURL = server.URL
+	h.proxies["form-service"] = h.createReverseProxy(service)
+	return h, up
+}
+
+// waitForWaiters waits until n requests wait for the call of key
+func waitForWaiters(t *testing.T, h *Handler, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		h.coalescer.mu.Lock()
+		waiting := 0
+		for _, call := range h.coalescer.calls {
+			waiting += call.waiters
+		}
+		h.coalescer.mu.Unlock()
+		if waiting >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("fewer than %d requests waiting", n)
+}
+
+func formRequest(userID, query string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/forms/form-1"+query, nil)
+	if userID != "" {
+		r = r.WithContext(context.WithValue(r.Context(), middleware.UserIDKey, userID))
+	}
+	return r
+}
+
+func TestCoalescingSharesOneUpstreamCall(t *testing.T) {
+	h, up := newCoalescingHandler(t, 0)
+	const parallel = 200
+
+	recorders := make([]*httptest.ResponseRecorder, parallel)
+	var wg sync.WaitGroup
+	for i := range recorders {
+		recorders[i] = httptest.NewRecorder()
+		// The same query in any order is the same request
+		query := "?a=1&b=2"
+		if i%2 == 1 {
+			query = "?b=2&a=1"
+		}
+		wg.Add(1)
+		go func(w *httptest.ResponseRecorder) {
+			defer wg.Done()
+			h.ProxyRoute(w, formRequest("", query), "form-service", routes.AuthOptional)
+		}(recorders[i])
+	}
+	waitForWaiters(t, h, parallel-1)
+	close(up.release)
+	wg.Wait()
+
+	if hits := up.hits.Load(); hits != 1 {
+		t.Errorf("%d upstream hits, want 1", hits)
+	}
+	for i, w := range recorders {
+		if w.Code != http.StatusOK || w.Body.String() != up.body || w.Header().Get("X-Upstream-Hit") != "1" ||
+			w.Header().Get("Content-Type") != "application/json" {
+			t.Fatalf("response %d = %d %v %q, want the shared response", i, w.Code, w.Header(), w.Body)
+		}
+	}
+	if got := testutil.ToFloat64(h.metrics.CoalescedRequests.WithLabelValues("form-service")); got != parallel-1 {
+		t.Errorf("coalesced_requests_total = %v, want %d", got, parallel-1)
+	}
+}
+
+func TestCoalescingKeepsUsersApart(t *testing.T) {
+	h, up := newCoalescingHandler(t, 0)
+
+	var wg sync.WaitGroup
+	for _, userID := range []string{"user-1", "user-2", "user-1"} {
+		wg.Add(1)
+		go func(userID string) {
+			defer wg.Done()
+			h.ProxyRoute(httptest.NewRecorder(), formRequest(userID, ""), "form-service", routes.AuthOptional)
+		}(userID)
+	}
+	waitForWaiters(t, h, 1)
+	// Give the other user's request the time to reach the upstream
+	deadline := time.Now().Add(5 * time.Second)
+	for up.hits.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	close(up.release)
+	wg.Wait()
+
+	if hits := up.hits.Load(); hits != 2 {
+		t.Errorf("%d upstream hits, want one per user", hits)
+	}
+}
+
+func TestCoalescingSharesPublicRoutesAcrossUsers(t *testing.T) {
+	h, up := newCoalescingHandler(t, 0)
+
+	var wg sync.WaitGroup
+	for _, userID := range []string{"user-1", "user-2", ""} {
+		wg.Add(1)
+		go func(userID string) {
+			defer wg.Done()
+			h.ProxyRoute(httptest.NewRecorder(), formRequest(userID, ""), "form-service", routes.AuthPublic)
+		}(userID)
+	}
+	waitForWaiters(t, h, 2)
+	close(up.release)
+	wg.Wait()
+
+	if hits := up.hits.Load(); hits != 1 {
+		t.Errorf("%d upstream hits, want 1", hits)
+	}
+}
+
+func TestCoalescingCapsTheSharedBody(t *testing.T) {
+	h, up := newCoalescingHandler(t, 16)
+
+	recorders := []*httptest.ResponseRecorder{httptest.NewRecorder(), httptest.NewRecorder(), httptest.NewRecorder()}
+	var wg sync.WaitGroup
+	for _, w := range recorders {
+		wg.Add(1)
+		go func(w *httptest.ResponseRecorder) {
+			defer wg.Done()
+			h.ProxyRoute(w, formRequest("", ""), "form-service", routes.AuthOptional)
+		}(w)
+	}
+	waitForWaiters(t, h, 2)
+	close(up.release)
+	wg.Wait()
+
+	// The response outgrew the cap: every request made its own call
+	if hits := up.hits.Load(); hits != 3 {
+		t.Errorf("%d upstream hits, want 3", hits)
+	}
+	for i, w := range recorders {
+		if w.Body.String() != up.body {
+			t.Errorf("response %d = %q, want the whole body", i, w.Body)
+		}
+	}
+}
+
+func TestCoalesceKeyKeepsRespondentsApart(t *testing.T) {
+	first, second := formRequest("", ""), formRequest("", "")
+	first.Header.Set("X-Respondent-Token", "respondent-1")
+	second.Header.Set("X-Respondent-Token", "respondent-2")
+
+	firstKey, _ := coalesceKey(first, routes.AuthPublic)
+	secondKey, _ := coalesceKey(second, routes.AuthPublic)
+	if firstKey == secondKey {
+		t.Error("requests of different respondent tokens share a key")
+	}
+}
+
+func TestCoalesceKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		request func() *http.Request
+		auth    routes.Auth
+		want    bool
+	}{
+		{"anonymous GET", func() *http.Request { return formRequest("", "") }, routes.AuthOptional, true},
+		{"POST", func() *http.Request {
+			return httptest.NewRequest(http.MethodPost, "/api/v1/forms", strings.NewReader("{}"))
+		}, routes.AuthOptional, false},
+		{"unresolved credentials", func() *http.Request {
+			r := formRequest("", "")
+			r.Header.Set("Authorization", "Bearer token")
+			return r
+		}, routes.AuthOptional, false},
+		{"credentials on a public route", func() *http.Request {
+			r := formRequest("", "")
+			r.Header.Set("Authorization", "Bearer token")
+			return r
+		}, routes.AuthPublic, true},
+		{"event stream", func() *http.Request {
+			r := formRequest("", "")
+			r.Header.Set("Accept", "text/event-stream")
+			return r
+		}, routes.AuthPublic, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := coalesceKey(tt.request(), tt.auth); ok != tt.want {
+				t.Errorf("coalesceKey ok = %v, want %v", ok, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/audit"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/faults"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
+)
+
+// FaultTokenHeader carries the fault injection token, which admins present
+// on top of their credentials
+const FaultTokenHeader = "X-Fault-Injection-Token"
+
+// FaultHandler serves the fault injection endpoints
+type FaultHandler struct {
+	injector *faults.Injector
+	token    string
+	audit    *audit.Recorder
+	logger   logger.Logger
+}
+
+// NewFaultHandler creates a new fault injection handler. Only callers
+// presenting token reach it; rule changes are recorded to recorder.
+func NewFaultHandler(injector *faults.Injector, token string, recorder *audit.Recorder, logger logger.Logger) *FaultHandler {
+	return &FaultHandler{
+		injector: injector,
+		token:    token,
+		audit:    recorder,
+		logger:   logger,
+	}
+}
+
+// FaultRulesRequest replaces the fault injection rules
+type FaultRulesRequest struct {
+	Rules []faults.Spec `json:"rules" binding:"dive"`
+} // @name FaultRulesRequest
+
+// FaultRulesResponse lists the active fault injection rules
+type FaultRulesResponse struct {
+	Rules []faults.Rule `json:"rules"`
+} // @name FaultRulesResponse
+
+// authorized rejects callers without the fault injection token
+func (h *FaultHandler) authorized(c *gin.Context) bool {
+	token := c.GetHeader(FaultTokenHeader)
+	if h.token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid fault injection token"})
+		return false
+	}
+	return true
+}
+
+// ListFaults godoc
+// @Summary List fault injection rules
+// @Description List the fault injection rules that have not expired. Only served outside production, with fault_injection enabled.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param X-Fault-Injection-Token header string true "Fault injection token"
+// @Success 200 {object} FaultRulesResponse
+// @Failure 401 {object} map[string]string
+// @Router /api/gateway/faults [get]
+func (h *FaultHandler) ListFaults(c *gin.Context) {
+	if !h.authorized(c) {
+		return
+	}
+	c.JSON(http.StatusOK, FaultRulesResponse{Rules: h.injector.Rules()})
+}
+
+// SetFaults godoc
+// @Summary Set fault injection rules
+// @Description Replace the fault injection rules; an empty list clears them. Each rule faults the calls to services of the requests whose gateway path matches its pattern, each attempt with its probability, until duration_seconds have passed: latency delays the call by latency_ms plus up to jitter_ms, error answers with status (503 by default) without calling the service, reset fails the call as a connection reset and truncate cuts the response body after truncate_bytes. Faults are injected below retries, so idempotent requests retried by their route policy can recover. Faulted requests are logged and counted with fault_injected=true. Only served outside production, with fault_injection enabled.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param X-Fault-Injection-Token header string true "Fault injection token"
+// @Param request body FaultRulesRequest true "Rules"
+// @Success 200 {object} FaultRulesResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /api/gateway/faults [put]
+func (h *FaultHandler) SetFaults(c *gin.Context) {
+	if !h.authorized(c) {
+		return
+	}
+
+	var req FaultRulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actor, _ := c.Request.Context().Value(middleware.UserIDKey).(string)
+	previous := h.injector.Rules()
+	rules, err := h.injector.Set(req.Rules, actor)
+	if errors.Is(err, faults.ErrInvalidRule) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Errorf("Failed to set fault injection rules: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to set fault injection rules"})
+		return
+	}
+
+	event := auditEvent(c, "faults.configured", "fault_rules", "gateway")
+	event.Before = previous
+	event.After = rules
+	h.audit.Record(event)
+	c.JSON(http.StatusOK, FaultRulesResponse{Rules: rules})
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/config"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/faults"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/policy"
+)
+
+// successRate proxies n GETs of a form through the fault injection, with
+// the retries of attempts, and returns the share answered with 200
+func successRate(h *Handler, injector *faults.Injector, attempts, n int) float64 {
+	p := policy.Policy{Pattern: "/forms/:id", Timeout: 5 * time.Second, RetryAttempts: attempts,
+		Breaker: policy.Breaker{FailureThreshold: n + 1, RecoveryTimeout: time.Second}}
+	proxy := middleware.FaultInjection(injector)(func(w http.ResponseWriter, r *http.Request) {
+		h.ProxyToService(w, r, "form-service")
+	})
+
+	succeeded := 0
+	for i := 0; i < n; i++ {
+		r := httptest.NewRequest(http.MethodGet, "/forms/form-1", nil)
+		w := httptest.NewRecorder()
+		proxy(w, r.WithContext(policy.NewContext(r.Context(), p)))
+		if w.Code == http.StatusOK {
+			succeeded++
+		}
+	}
+	return float64(succeeded) / float64(n)
+}
+
+func TestInjectedErrorsRecoveredByRetries(t *testing.T) {
+	h, _ := newPolicyHandler(t, http.StatusOK, 0)
+	injector := faults.New(config.FaultInjectionConfig{MaxDuration: time.Hour}, h.metrics, h.logger)
+	_, err := injector.Set([]faults.Spec{{
+		Path: "/forms/*", Type: faults.Error, Status: http.StatusServiceUnavailable,
+		Probability: 0.5, DurationSeconds: 60,
+	}}, "admin-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Half the calls fail without retries
+	if rate := successRate(h, injector, 0, 400); rate < 0.35 || rate > 0.65 {
+		t.Errorf("success rate without retries = %.2f, want about 0.5", rate)
+	}
+	// Faults hit attempts, so retried GETs mostly recover
+	if rate := successRate(h, injector, 3, 400); rate < 0.85 {
+		t.Errorf("success rate with 3 retries = %.2f, want above 0.85", rate)
+	}
+	if got := testutil.ToFloat64(h.metrics.FaultsInjected.WithLabelValues("form-service", string(faults.Error))); got < 300 {
+		t.Errorf("faults_injected_total = %v, want every faulted attempt counted", got)
+	}
+
+	// Other routes are left alone
+	r := httptest.NewRequest(http.MethodGet, "/responses", nil)
+	w := httptest.NewRecorder()
+	middleware.FaultInjection(injector)(func(w http.ResponseWriter, r *http.Request) {
+		h.ProxyToService(w, r, "form-service")
+		if faults.Injected(r.Context()) {
+			t.Error("a request outside the rule paths was faulted")
+		}
+	})(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("GET /responses = %d, want 200", w.Code)
+	}
+}
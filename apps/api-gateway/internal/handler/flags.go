@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/audit"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
+	"github.com/Mir00r/X-Form-Backend/shared/flags"
+)
+
+// FlagHandler serves the feature flag management endpoints
+type FlagHandler struct {
+	flags  *flags.Client
+	audit  *audit.Recorder
+	logger logger.Logger
+}
+
+// NewFlagHandler creates a new feature flag handler. Flag changes are
+// recorded to recorder.
+func NewFlagHandler(client *flags.Client, recorder *audit.Recorder, logger logger.Logger) *FlagHandler {
+	return &FlagHandler{
+		flags:  client,
+		audit:  recorder,
+		logger: logger,
+	}
+}
+
+// ListFlags godoc
+// @Summary List feature flags
+// @Description List the feature flags as the gateway evaluates them: the flags defined in configuration, overridden by those changed at runtime
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/admin/flags [get]
+func (h *FlagHandler) ListFlags(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"flags": h.flags.Flags()})
+}
+
+// SetFlag godoc
+// @Summary Set feature flag
+// @Description Create or replace a feature flag. Every service picks the change up on its next refresh, within a few seconds. percentage rolls the flag out to a stable share of users, or of organizations when by is organization; allowed_organizations always get the flag while it is enabled.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body flags.Flag true "Flag"
+// @Success 200 {object} flags.Flag
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/admin/flags [put]
+func (h *FlagHandler) SetFlag(c *gin.Context) {
+	var flag flags.Flag
+	if err := c.ShouldBindJSON(&flag); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := flag.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actor, _ := c.Request.Context().Value(middleware.UserIDKey).(string)
+	previous, err := h.flags.Set(c.Request.Context(), flag, actor)
+	if err != nil {
+		h.logger.Errorf("Failed to set feature flag %s: %v", flag.Name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to set feature flag"})
+		return
+	}
+	current, _ := h.flags.Get(flag.Name)
+
+	h.logger.Infof("Feature flag %s set by %s", flag.Name, actor)
+	event := auditEvent(c, "flag.updated", "feature_flag", flag.Name)
+	if previous != nil {
+		event.Before = previous
+	}
+	event.After = current
+	h.audit.Record(event)
+	c.JSON(http.StatusOK, current)
+}
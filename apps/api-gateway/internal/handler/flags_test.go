@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
+	"github.com/Mir00r/X-Form-Backend/shared/flags"
+)
+
+// flagStore keeps flags in a map
+type flagStore map[string]flags.Flag
+
+func (s flagStore) Load(context.Context) (map[string]flags.Flag, error) {
+	stored := make(map[string]flags.Flag, len(s))
+	for name, flag := range s {
+		stored[name] = flag
+	}
+	return stored, nil
+}
+
+func (s flagStore) Save(_ context.Context, flag flags.Flag) error {
+	s[flag.Name] = flag
+	return nil
+}
+
+func TestFlagHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := flagStore{}
+	client := flags.NewClient(store, []flags.Flag{{Name: "new_validator", Enabled: true, Percentage: 10}})
+	log := logger.New(logger.LogConfig{Level: "fatal", Format: "json", Output: "stderr"})
+	h := NewFlagHandler(client, nil, log)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), middleware.UserIDKey, "admin-1"))
+	})
+	router.GET("/flags", h.ListFlags)
+	router.PUT("/flags", h.SetFlag)
+
+	put := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/flags", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := put(`{"name":"new_validator","enabled":true,"percentage":50,"allowed_organizations":["org-1"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT = %d %s, want 200", rec.Code, rec.Body.String())
+	}
+	var flag flags.Flag
+	if err := json.Unmarshal(rec.Body.Bytes(), &flag); err != nil || flag.Percentage != 50 || flag.UpdatedBy != "admin-1" {
+		t.Errorf("PUT returned %s, want the flag set by the caller", rec.Body.String())
+	}
+	if store["new_validator"].Percentage != 50 {
+		t.Error("expected the flag to be stored for the other services")
+	}
+
+	if rec := put(`{"name":"new_validator","percentage":-1}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("PUT of an invalid flag = %d, want 400", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/flags", nil))
+	var list struct {
+		Flags []flags.Flag `json:"flags"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || len(list.Flags) != 1 || list.Flags[0].Percentage != 50 {
+		t.Errorf("GET returned %s, want the updated flag", rec.Body.String())
+	}
+}
@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/audit"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
+)
+
+// FormAdminHandler serves the form cleanups of the form service and the
+// usage of organizations to admins
+type FormAdminHandler struct {
+	url          string
+	serviceToken string
+	client       *http.Client
+	audit        *audit.Recorder
+	logger       logger.Logger
+}
+
+// NewFormAdminHandler creates a new form admin handler relaying to the form
+// service at formServiceURL, authenticated by serviceToken. Started and
+// cancelled cleanups are recorded to recorder.
+func NewFormAdminHandler(formServiceURL, serviceToken string, timeout time.Duration, recorder *audit.Recorder, logger logger.Logger) *FormAdminHandler {
+	return &FormAdminHandler{
+		url:          strings.TrimSuffix(formServiceURL, "/"),
+		serviceToken: serviceToken,
+		client:       &http.Client{Timeout: timeout},
+		audit:        recorder,
+		logger:       logger,
+	}
+}
+
+// CleanupForms godoc
+// @Summary Clean up abandoned forms
+// @Description Archive the forms last updated before inactive_before, or the forms without responses, narrowed by owner_id, organization_id and status. Each form is exported to storage, soft deleted and announced with form.archived. With dry_run the matching forms are counted and a sample of their IDs returned; otherwise a job is queued and its progress read from the returned ID.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body map[string]interface{} true "Cleanup criteria"
+// @Success 200 {object} map[string]interface{}
+// @Success 202 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /api/v1/admin/forms/cleanup [post]
+func (h *FormAdminHandler) CleanupForms(c *gin.Context) {
+	var criteria map[string]interface{}
+	if err := c.ShouldBindJSON(&criteria); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	criteria["requested_by"], _ = c.Request.Context().Value(middleware.UserIDKey).(string)
+
+	body, err := json.Marshal(criteria)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status, job, ok := h.forward(c, http.MethodPost, "/internal/admin/forms/cleanup", body)
+	if ok && status == http.StatusAccepted {
+		h.audit.Record(auditEvent(c, "forms.cleanup.started", "form_cleanup", jobID(job)))
+	}
+}
+
+// GetCleanupJob godoc
+// @Summary Get form cleanup
+// @Description Status of a form cleanup job and the number of forms matched, archived and failed so far
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Cleanup job ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /api/v1/admin/forms/cleanup/{id} [get]
+func (h *FormAdminHandler) GetCleanupJob(c *gin.Context) {
+	h.forward(c, http.MethodGet, "/internal/admin/forms/cleanup/"+c.Param("id"), nil)
+}
+
+// CancelCleanupJob godoc
+// @Summary Cancel form cleanup
+// @Description Cancel a queued or running form cleanup job. A running job stops after the page of forms it is archiving; forms already archived stay archived.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Cleanup job ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /api/v1/admin/forms/cleanup/{id} [delete]
+func (h *FormAdminHandler) CancelCleanupJob(c *gin.Context) {
+	status, _, ok := h.forward(c, http.MethodDelete, "/internal/admin/forms/cleanup/"+c.Param("id"), nil)
+	if ok && status == http.StatusOK {
+		h.audit.Record(auditEvent(c, "forms.cleanup.cancelled", "form_cleanup", c.Param("id")))
+	}
+}
+
+// GetUsage godoc
+// @Summary Get organization usage
+// @Description The forms created, responses collected, bytes of files uploaded and exports run by an organization in a month, with the quotas of its plan and its active forms
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param orgId path string true "Organization ID"
+// @Param month query string false "Month as YYYY-MM, the current one by default"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /api/v1/admin/usage/{orgId} [get]
+func (h *FormAdminHandler) GetUsage(c *gin.Context) {
+	path := "/internal/admin/usage/" + url.PathEscape(c.Param("orgId"))
+	if month := c.Query("month"); month != "" {
+		path += "?" + url.Values{"month": {month}}.Encode()
+	}
+	h.forward(c, http.MethodGet, path, nil)
+}
+
+// forward relays the request to path of the form service and answers with
+// its response, which is returned along with its status. ok is false when
+// the form service could not be reached.
+func (h *FormAdminHandler) forward(c *gin.Context, method, path string, body []byte) (status int, respBody []byte, ok bool) {
+	req, err := http.NewRequestWithContext(c.Request.Context(), method, h.url+path, bytes.NewReader(body))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reach form service"})
+		return 0, nil, false
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if requestID := c.GetHeader("X-Request-ID"); requestID != "" {
+		req.Header.Set("X-Request-ID", requestID)
+	}
+	setServiceToken(req, h.serviceToken)
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		h.logger.Errorf("Failed to reach the form service for %s %s: %v", method, path, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "form service unavailable"})
+		return 0, nil, false
+	}
+	defer resp.Body.Close()
+
+	respBody, err = io.ReadAll(resp.Body)
+	if err != nil {
+		h.logger.Errorf("Failed to read the form service response for a form cleanup: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "form service unavailable"})
+		return 0, nil, false
+	}
+
+	c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), respBody)
+	return resp.StatusCode, respBody, true
+}
+
+// jobID reads the ID of the cleanup job answered by the form service
+func jobID(body []byte) string {
+	var job struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(body, &job)
+	return job.ID
+}
+
+// setServiceToken authenticates a call to the internal API of the form
+// service
+func setServiceToken(req *http.Request, token string) {
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
+)
+
+func TestCleanupFormsRelaysRequester(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/internal/admin/forms/cleanup" {
+			t.Errorf("path = %s, want /internal/admin/forms/cleanup", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer service-token" {
+			t.Errorf("Authorization = %q, want the service token", got)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"id":"job-1","status":"queued"}`))
+	}))
+	defer server.Close()
+
+	log := logger.New(logger.LogConfig{Level: "fatal", Format: "json", Output: "stderr"})
+	h := NewFormAdminHandler(server.URL, "service-token", time.Second, nil, log)
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/forms/cleanup",
+		strings.NewReader(`{"zero_responses":true,"requested_by":"someone-else"}`))
+	req.Header.Set("Content-Type", "application/json")
+	c.Request = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "admin-1"))
+
+	h.CleanupForms(c)
+	if w.Code != http.StatusAccepted || !strings.Contains(w.Body.String(), "job-1") {
+		t.Errorf("POST = %d %s, want 202 with the job", w.Code, w.Body.String())
+	}
+	if got["requested_by"] != "admin-1" || got["zero_responses"] != true {
+		t.Errorf("form service got %v, want the criteria requested by admin-1", got)
+	}
+}
+
+func TestCleanupFormsUnavailable(t *testing.T) {
+	log := logger.New(logger.LogConfig{Level: "fatal", Format: "json", Output: "stderr"})
+	h := NewFormAdminHandler("http://127.0.0.1:1", "service-token", time.Second, nil, log)
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/admin/forms/cleanup/job-1", nil)
+	c.Params = gin.Params{{Key: "id", Value: "job-1"}}
+
+	h.GetCleanupJob(c)
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("GET = %d, want 502", w.Code)
+	}
+}
+
+func TestGetUsageRelaysMonth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/internal/admin/usage/org-1" || r.URL.Query().Get("month") != "2026-09" {
+			t.Errorf("relayed %s, want /internal/admin/usage/org-1?month=2026-09", r.URL)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"organization_id":"org-1","month":"2026-09","responses":42}`))
+	}))
+	defer server.Close()
+
+	log := logger.New(logger.LogConfig{Level: "fatal", Format: "json", Output: "stderr"})
+	h := NewFormAdminHandler(server.URL, "service-token", time.Second, nil, log)
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/admin/usage/org-1?month=2026-09", nil)
+	c.Params = gin.Params{{Key: "orgId", Value: "org-1"}}
+
+	h.GetUsage(c)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"responses":42`) {
+		t.Errorf("GET = %d %s, want the usage", w.Code, w.Body.String())
+	}
+}
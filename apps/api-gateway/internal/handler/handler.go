@@ -10,9 +10,15 @@ import (
 	"net/http/httputil"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/config"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/faults"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/mtls"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/policy"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/routes"
 	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
 	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/metrics"
 )
@@ -24,6 +30,21 @@ type Handler struct {
 	metrics  *metrics.Collector
 	services map[string]*Service
 	proxies  map[string]*httputil.ReverseProxy
+
+	// Connection pools to the services, over mTLS when upstreamTLS is set
+	upstreamTLS *mtls.Source
+	transports  map[string]http.RoundTripper
+
+	// coalescer shares upstream GETs, nil when coalescing is disabled
+	coalescer *coalescer
+	// cache keeps the responses of routes with a cache TTL, nil when
+	// caching is disabled
+	cache *responseCache
+
+	// routeBreakers are the circuit breakers of the route policies, by
+	// service and pattern
+	breakersMu    sync.Mutex
+	routeBreakers map[string]*CircuitBreaker
 }
 
 // Service represents an upstream service configuration
@@ -43,6 +64,7 @@ type CircuitBreaker struct {
 	FailureThreshold  int           `json:"failure_threshold"`
 	RecoveryTimeout   time.Duration `json:"recovery_timeout"`
 	TestRequestVolume int           `json:"test_request_volume"`
+	mu                sync.Mutex
 	state             CircuitState
 	failures          int
 	lastFailureTime   time.Time
@@ -65,14 +87,25 @@ type LoadBalancer struct {
 	current   int
 }
 
-// NewHandler creates a new handler instance
-func NewHandler(cfg *config.Config, logger logger.Logger, metrics *metrics.Collector) *Handler {
+// NewHandler creates a new handler instance. With upstreamTLS, the services
+// are reached over mTLS.
+func NewHandler(cfg *config.Config, logger logger.Logger, metrics *metrics.Collector, upstreamTLS *mtls.Source) *Handler {
 	h := &Handler{
-		config:   cfg,
-		logger:   logger,
-		metrics:  metrics,
-		services: make(map[string]*Service),
-		proxies:  make(map[string]*httputil.ReverseProxy),
+		config:        cfg,
+		logger:        logger,
+		metrics:       metrics,
+		services:      make(map[string]*Service),
+		proxies:       make(map[string]*httputil.ReverseProxy),
+		upstreamTLS:   upstreamTLS,
+		transports:    make(map[string]http.RoundTripper),
+		routeBreakers: make(map[string]*CircuitBreaker),
+	}
+
+	if cfg.Proxy.Coalesce {
+		h.coalescer = newCoalescer(cfg.Proxy.CoalesceMaxBodyBytes)
+	}
+	if cfg.Proxy.Cache {
+		h.cache = newResponseCache(cfg.Proxy.CacheMaxEntries, cfg.Proxy.CacheMaxBodyBytes)
 	}
 
 	// Initialize services from configuration
@@ -198,15 +231,72 @@ func (h *Handler) initializeServices() {
 	// Initialize services and proxies
 	for name, service := range services {
 		h.services[name] = service
+		h.transports[name] = h.newTransport(name)
 		h.proxies[name] = h.createReverseProxy(service)
 	}
 }
 
+// newTransport returns the connection pool to a service, retrying requests
+// as their route policy allows. Faults are injected below the retries, into
+// each attempt.
+func (h *Handler) newTransport(name string) http.RoundTripper {
+	if h.upstreamTLS == nil {
+		return &retryTransport{next: faults.Transport(name, mtls.NewTransport(h.config.Proxy)), backoff: retryBackoff}
+	}
+	mtlsConfig := h.config.Security.MTLS
+	return &retryTransport{
+		next:    faults.Transport(name, h.upstreamTLS.Transport(h.config.Proxy, mtlsConfig.Identities[name], mtlsConfig.Enforce)),
+		backoff: retryBackoff,
+	}
+}
+
+// CloseIdleConnections closes the idle connections to every service, once
+// the gateway has stopped proxying
+func (h *Handler) CloseIdleConnections() {
+	for _, transport := range h.transports {
+		closeIdleConnections(transport)
+	}
+}
+
+// circuitBreaker returns the breaker guarding a request to service: the one
+// of the route policy attached to the request, with the thresholds of the
+// policy, or else the breaker of the service
+func (h *Handler) circuitBreaker(service *Service, r *http.Request) *CircuitBreaker {
+	p, ok := policy.FromContext(r.Context())
+	if !ok || service.CircuitBreaker == nil || !service.CircuitBreaker.Enabled {
+		return service.CircuitBreaker
+	}
+
+	key := service.Name + "\x00" + p.Pattern
+	h.breakersMu.Lock()
+	defer h.breakersMu.Unlock()
+	cb, ok := h.routeBreakers[key]
+	if !ok {
+		cb = &CircuitBreaker{
+			Enabled:           true,
+			FailureThreshold:  p.Breaker.FailureThreshold,
+			RecoveryTimeout:   p.Breaker.RecoveryTimeout,
+			TestRequestVolume: service.CircuitBreaker.TestRequestVolume,
+		}
+		h.routeBreakers[key] = cb
+	}
+	return cb
+}
+
+// timeout returns how long a request to service may take
+func timeout(service *Service, r *http.Request) time.Duration {
+	if p, ok := policy.FromContext(r.Context()); ok && p.Timeout > 0 {
+		return p.Timeout
+	}
+	return service.Timeout
+}
+
 // createReverseProxy creates a reverse proxy for a service
 func (h *Handler) createReverseProxy(service *Service) *httputil.ReverseProxy {
 	target, _ := url.Parse(service.BaseURL)
 
 	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.Transport = h.transports[service.Name]
 
 	// Customize the director to handle request transformation
 	originalDirector := proxy.Director
@@ -287,6 +377,23 @@ func (h *Handler) transformResponse(resp *http.Response, service *Service) error
 	resp.Header.Set("X-Served-By", service.Name)
 	resp.Header.Set("X-Gateway", "x-form-api-gateway")
 
+	// Responses without caching headers of their own get the TTL of the route
+	if req := resp.Request; req != nil && req.Method == http.MethodGet && resp.StatusCode == http.StatusOK &&
+		resp.Header.Get("Cache-Control") == "" && resp.Header.Get("Set-Cookie") == "" {
+		if p, ok := policy.FromContext(req.Context()); ok && p.CacheTTL > 0 {
+			resp.Header.Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(p.CacheTTL.Seconds())))
+		}
+	}
+
+	// Routes hiding the internal URLs of the service rewrite its responses
+	if req := resp.Request; req != nil {
+		if p, ok := policy.FromContext(req.Context()); ok && p.Rewrite != nil {
+			if err := rewriteResponse(resp, service.BaseURL, p.Rewrite); err != nil {
+				return err
+			}
+		}
+	}
+
 	// Record metrics
 	h.metrics.RecordUpstreamRequest(
 		service.Name,
@@ -304,21 +411,28 @@ func (h *Handler) handleProxyError(w http.ResponseWriter, r *http.Request, servi
 	h.metrics.RecordUpstreamError(service.Name, "proxy_error")
 
 	// Update circuit breaker
-	if service.CircuitBreaker != nil && service.CircuitBreaker.Enabled {
-		h.recordFailure(service.CircuitBreaker)
+	if cb := h.circuitBreaker(service, r); cb != nil && cb.Enabled {
+		h.recordFailure(cb)
 	}
 
 	// Log error
 	h.logger.WithFields(map[string]interface{}{
-		"service":    service.Name,
-		"error":      err.Error(),
-		"method":     r.Method,
-		"path":       r.URL.Path,
-		"request_id": r.Header.Get("X-Request-ID"),
+		"service":        service.Name,
+		"error":          err.Error(),
+		"method":         r.Method,
+		"path":           r.URL.Path,
+		"request_id":     r.Header.Get("X-Request-ID"),
+		"fault_injected": faults.Injected(r.Context()),
 	}).Error("Upstream service error")
 
+	// Report the failure, whose ID support cross-references
+	errorID := middleware.ReportError(r, fmt.Errorf("%s: %w", service.Name, err), http.StatusBadGateway)
+
 	// Return appropriate error response
 	w.Header().Set("Content-Type", "application/json")
+	if errorID != "" {
+		w.Header().Set(middleware.ErrorIDHeader, errorID)
+	}
 	w.WriteHeader(http.StatusBadGateway)
 
 	response := map[string]interface{}{
@@ -327,6 +441,7 @@ func (h *Handler) handleProxyError(w http.ResponseWriter, r *http.Request, servi
 			"message":    "Upstream service is currently unavailable",
 			"service":    service.Name,
 			"request_id": r.Header.Get("X-Request-ID"),
+			"error_id":   errorID,
 		},
 	}
 
@@ -349,8 +464,9 @@ func (h *Handler) ProxyHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check circuit breaker
-	if service.CircuitBreaker != nil && service.CircuitBreaker.Enabled {
-		if !h.checkCircuitBreaker(service.CircuitBreaker) {
+	cb := h.circuitBreaker(service, r)
+	if cb != nil && cb.Enabled {
+		if !h.checkCircuitBreaker(cb) {
 			h.handleCircuitOpen(w, r, serviceName)
 			return
 		}
@@ -367,7 +483,7 @@ func (h *Handler) ProxyHandler(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
 
 	// Add timeout to request context
-	ctx, cancel := context.WithTimeout(r.Context(), service.Timeout)
+	ctx, cancel := context.WithTimeout(r.Context(), timeout(service, r))
 	defer cancel()
 	r = r.WithContext(ctx)
 
@@ -375,8 +491,8 @@ func (h *Handler) ProxyHandler(w http.ResponseWriter, r *http.Request) {
 	proxy.ServeHTTP(w, r)
 
 	// Record success
-	if service.CircuitBreaker != nil && service.CircuitBreaker.Enabled {
-		h.recordSuccess(service.CircuitBreaker)
+	if cb != nil && cb.Enabled {
+		h.recordSuccess(cb)
 	}
 
 	// Record metrics (duration calculation is simplified here)
@@ -409,6 +525,8 @@ func (h *Handler) determineService(path string) string {
 
 // checkCircuitBreaker checks if requests can pass through the circuit breaker
 func (h *Handler) checkCircuitBreaker(cb *CircuitBreaker) bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
 	now := time.Now()
 
 	switch cb.state {
@@ -438,6 +556,8 @@ func (h *Handler) checkCircuitBreaker(cb *CircuitBreaker) bool {
 
 // recordFailure records a failure in the circuit breaker
 func (h *Handler) recordFailure(cb *CircuitBreaker) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
 	cb.failures++
 	cb.lastFailureTime = time.Now()
 
@@ -454,6 +574,8 @@ func (h *Handler) recordFailure(cb *CircuitBreaker) {
 
 // recordSuccess records a success in the circuit breaker
 func (h *Handler) recordSuccess(cb *CircuitBreaker) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
 	if cb.state == CircuitHalfOpen {
 		cb.failures = 0
 		cb.state = CircuitClosed
@@ -549,7 +671,8 @@ func (h *Handler) checkSingleServiceHealth(service *Service) ServiceHealth {
 
 	// Set timeout
 	client := &http.Client{
-		Timeout: time.Second * 5,
+		Timeout:   time.Second * 5,
+		Transport: h.transports[service.Name],
 	}
 
 	// Make request
@@ -686,7 +809,7 @@ type GatewayHandlers struct {
 
 // NewGatewayHandlers creates a new instance of GatewayHandlers
 func NewGatewayHandlers(cfg *config.Config, logger logger.Logger, metrics *metrics.Collector) *GatewayHandlers {
-	handler := NewHandler(cfg, logger, metrics)
+	handler := NewHandler(cfg, logger, metrics, nil)
 	return &GatewayHandlers{
 		handler: handler,
 	}
@@ -726,8 +849,9 @@ func (h *Handler) ProxyToService(w http.ResponseWriter, r *http.Request, service
 	}
 
 	// Check circuit breaker
-	if service.CircuitBreaker != nil && service.CircuitBreaker.Enabled {
-		if !h.checkCircuitBreaker(service.CircuitBreaker) {
+	cb := h.circuitBreaker(service, r)
+	if cb != nil && cb.Enabled {
+		if !h.checkCircuitBreaker(cb) {
 			h.handleCircuitOpen(w, r, serviceName)
 			return
 		}
@@ -744,7 +868,7 @@ func (h *Handler) ProxyToService(w http.ResponseWriter, r *http.Request, service
 	start := time.Now()
 
 	// Add timeout to request context
-	ctx, cancel := context.WithTimeout(r.Context(), service.Timeout)
+	ctx, cancel := context.WithTimeout(r.Context(), timeout(service, r))
 	defer cancel()
 	r = r.WithContext(ctx)
 
@@ -755,14 +879,61 @@ func (h *Handler) ProxyToService(w http.ResponseWriter, r *http.Request, service
 	proxy.ServeHTTP(w, r)
 
 	// Record success if the request was successful
-	if service.CircuitBreaker != nil && service.CircuitBreaker.Enabled {
-		h.recordSuccess(service.CircuitBreaker)
+	if cb != nil && cb.Enabled {
+		h.recordSuccess(cb)
 	}
 
 	// Record metrics (duration calculation is simplified here)
 	h.metrics.RecordUpstreamRequest(serviceName, r.Method, 200, time.Since(start))
 }
 
+// ProxyRoute proxies a request below a gateway prefix to a service. GETs of
+// routes with a cache TTL are served from the response cache; identical
+// concurrent GETs share one upstream call. auth is the authentication of the
+// route, which decides whether different callers may share a response.
+func (h *Handler) ProxyRoute(w http.ResponseWriter, r *http.Request, serviceName string, auth routes.Auth) {
+	if auth != routes.AuthPublic {
+		// The owner of a resource and other callers may see different views
+		w.Header().Add("Vary", "Authorization")
+	}
+
+	key, ok := resourceKey(r, auth)
+	if !ok {
+		h.ProxyToService(w, r, serviceName)
+		// Once written, the cached views of the resource are stale
+		if h.cache != nil && r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodOptions {
+			h.cache.purge(r.URL.Path)
+		}
+		return
+	}
+	if p, found := policy.FromContext(r.Context()); found && p.CacheTTL > 0 && h.cache != nil {
+		fetch := func(w http.ResponseWriter, r *http.Request) {
+			key, _ := coalesceKey(r, auth)
+			h.coalesce(w, r, serviceName, key)
+		}
+		result := h.cache.serve(w, r, serviceName+"\x00"+key, p.CacheTTL, fetch)
+		h.metrics.RecordCachedResponse(serviceName, result)
+		return
+	}
+	key, _ = coalesceKey(r, auth)
+	h.coalesce(w, r, serviceName, key)
+}
+
+// coalesce proxies the GET r, sharing the upstream call of the identical
+// requests in flight under key
+func (h *Handler) coalesce(w http.ResponseWriter, r *http.Request, serviceName, key string) {
+	if h.coalescer == nil {
+		h.ProxyToService(w, r, serviceName)
+		return
+	}
+	proxy := func(w http.ResponseWriter, r *http.Request) {
+		h.ProxyToService(w, r, serviceName)
+	}
+	if h.coalescer.do(w, r, serviceName+"\x00"+key, proxy) {
+		h.metrics.RecordCoalescedRequest(serviceName)
+	}
+}
+
 // SetupRoutes sets up the HTTP routes for the gateway
 func (h *Handler) SetupRoutes() *http.ServeMux {
 	mux := http.NewServeMux()
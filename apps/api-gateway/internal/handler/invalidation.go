@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/metrics"
+)
+
+// formCacheRoots are the upstream paths the views of a form are cached
+// below, each followed by the ID of the form
+var formCacheRoots = []string{"/api/v1/forms/", "/api/v1/public/forms/", "/api/v1/analytics/forms/"}
+
+// formSlugRoot is the upstream path published forms are resolved below,
+// followed by their slug
+const formSlugRoot = "/api/v1/public/forms/by-slug/"
+
+// Results of the invalidations received, as reported in the metrics
+const (
+	invalidationPurged    = "purged"
+	invalidationCoalesced = "coalesced"
+	invalidationInvalid   = "invalid"
+)
+
+// formInvalidation is a message of the invalidation channel, published by
+// the event bus when a form changed
+type formInvalidation struct {
+	FormID string    `json:"form_id"`
+	Slugs  []string  `json:"slugs"`
+	At     time.Time `json:"at"`
+}
+
+// CacheInvalidator purges the cached views of the forms changed, whichever
+// replica or service they were changed through, as the event bus announces
+// them. Invalidations of a form received within the coalescing window purge
+// it once.
+type CacheInvalidator struct {
+	cache   *responseCache
+	metrics *metrics.Collector
+	logger  logger.Logger
+	window  time.Duration
+	now     func() time.Time
+
+	mu      sync.Mutex
+	pending map[string]*formInvalidation
+}
+
+// NewCacheInvalidator creates the invalidator of the response cache of h,
+// coalescing invalidations over window. It returns nil when caching is
+// disabled.
+func (h *Handler) NewCacheInvalidator(window time.Duration) *CacheInvalidator {
+	if h.cache == nil {
+		return nil
+	}
+	return &CacheInvalidator{
+		cache:   h.cache,
+		metrics: h.metrics,
+		logger:  h.logger,
+		window:  window,
+		now:     time.Now,
+		pending: make(map[string]*formInvalidation),
+	}
+}
+
+// Run purges the forms invalidated on channel until ctx is done. The
+// subscription is restored by the client after a reconnect; invalidations
+// published meanwhile are missed, and the cache TTL bounds how stale their
+// views stay.
+func (i *CacheInvalidator) Run(ctx context.Context, client *redis.Client, channel string) {
+	pubsub := client.Subscribe(ctx, channel)
+	defer pubsub.Close()
+
+	messages := pubsub.Channel()
+	var flush <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+			if i.receive(message.Payload) && flush == nil {
+				flush = time.After(i.window)
+			}
+		case <-flush:
+			flush = nil
+			i.flush()
+		}
+	}
+}
+
+// receive adds an invalidation to those pending, merging it with the one of
+// its form. It reports whether the invalidation was valid.
+func (i *CacheInvalidator) receive(payload string) bool {
+	var invalidation formInvalidation
+	if err := json.Unmarshal([]byte(payload), &invalidation); err != nil || invalidation.FormID == "" {
+		i.logger.Warnf("Dropping invalid cache invalidation %q: %v", payload, err)
+		i.metrics.RecordCacheInvalidation(invalidationInvalid)
+		return false
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	pending, ok := i.pending[invalidation.FormID]
+	if !ok {
+		i.pending[invalidation.FormID] = &invalidation
+		return true
+	}
+	i.metrics.RecordCacheInvalidation(invalidationCoalesced)
+	if invalidation.At.Before(pending.At) {
+		pending.At = invalidation.At
+	}
+	for _, slug := range invalidation.Slugs {
+		if !containsString(pending.Slugs, slug) {
+			pending.Slugs = append(pending.Slugs, slug)
+		}
+	}
+	return true
+}
+
+// flush purges the views of the forms pending invalidation
+func (i *CacheInvalidator) flush() {
+	i.mu.Lock()
+	pending := i.pending
+	i.pending = make(map[string]*formInvalidation)
+	i.mu.Unlock()
+
+	for _, invalidation := range pending {
+		for _, root := range formCacheRoots {
+			i.cache.purgeTree(root + invalidation.FormID)
+		}
+		for _, slug := range invalidation.Slugs {
+			i.cache.purgeTree(formSlugRoot + slug)
+		}
+		i.metrics.RecordCacheInvalidation(invalidationPurged)
+		if !invalidation.At.IsZero() {
+			latency := i.now().Sub(invalidation.At)
+			if latency < 0 {
+				latency = 0
+			}
+			i.metrics.RecordCacheInvalidationLatency(latency)
+		}
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
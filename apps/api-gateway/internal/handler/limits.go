@@ -0,0 +1,306 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/apikey"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/policy"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
+)
+
+// limitsCacheTTL is how long the limits of a client are served from memory
+const limitsCacheTTL = 30 * time.Second
+
+// LimitsHandler reports the effective limits of the caller, assembled from
+// the rate limiter, the route policies and the quotas of the form service
+type LimitsHandler struct {
+	limiter  *middleware.RateLimiter
+	policies *policy.Resolver
+	url      string
+	client   *http.Client
+	logger   logger.Logger
+	now      func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cachedLimits
+}
+
+// cachedLimits are the limits reported to a client until expires
+type cachedLimits struct {
+	limits  LimitsResponse
+	expires time.Time
+}
+
+// NewLimitsHandler creates a handler reporting the limits of limiter and
+// policies, and the quotas the form service at formServiceURL reports
+func NewLimitsHandler(limiter *middleware.RateLimiter, policies *policy.Resolver, formServiceURL string, timeout time.Duration, logger logger.Logger) *LimitsHandler {
+	return &LimitsHandler{
+		limiter:  limiter,
+		policies: policies,
+		url:      strings.TrimSuffix(formServiceURL, "/"),
+		client:   &http.Client{Timeout: timeout},
+		logger:   logger,
+		now:      time.Now,
+		cache:    make(map[string]cachedLimits),
+	}
+}
+
+// LimitsResponse is the effective limits of the caller
+type LimitsResponse struct {
+	// Client is who the requests of the caller are counted for
+	Client    string         `json:"client" example:"user:4b7e9c2a-5d1f-4e8a-9c3b-2f6d8e1a7b90"`
+	RateLimit RateLimits     `json:"rate_limit"`
+	BodySize  BodySizeLimits `json:"body_size"`
+	// Quotas and Exports are those of the organization of the caller,
+	// absent for API keys and while the form service is unavailable
+	Quotas  *OrganizationQuotas `json:"quotas,omitempty"`
+	Exports *ExportLimits       `json:"exports,omitempty"`
+} // @name LimitsResponse
+
+// RateLimits are the request rates allowed to the caller. Routes whose
+// policy names a tier are limited by the tier, routes matching an endpoint
+// limit by it, and the others by the default limit.
+type RateLimits struct {
+	Enabled   bool                `json:"enabled"`
+	Default   RateLimitWindow     `json:"default"`
+	Tiers     []TierRateLimit     `json:"tiers"`
+	Endpoints []EndpointRateLimit `json:"endpoints"`
+} // @name RateLimits
+
+// RateLimitWindow allows Limit requests in any window of WindowSeconds
+type RateLimitWindow struct {
+	Limit         int `json:"limit" example:"100"`
+	WindowSeconds int `json:"window_seconds" example:"60"`
+	// Override is set when the limit is set for the caller in particular
+	Override bool `json:"override,omitempty"`
+} // @name RateLimitWindow
+
+// TierRateLimit is the limit of the routes whose policy names the tier
+type TierRateLimit struct {
+	Name string `json:"name" example:"submissions"`
+	RateLimitWindow
+	// Routes are the path patterns of the policies naming the tier
+	Routes []string `json:"routes"`
+} // @name TierRateLimit
+
+// EndpointRateLimit is the limit of the paths matching Pattern, each counted
+// on its own
+type EndpointRateLimit struct {
+	Pattern string `json:"pattern" example:"/api/v1/auth/*"`
+	RateLimitWindow
+} // @name EndpointRateLimit
+
+// BodySizeLimits bound the size of request bodies, 0 when unbounded
+type BodySizeLimits struct {
+	DefaultBytes int64 `json:"default_bytes" example:"10485760"`
+	// Routes are the path patterns whose policy sets another bound
+	Routes []RouteBodySize `json:"routes"`
+} // @name BodySizeLimits
+
+// RouteBodySize bounds the bodies of the requests to the paths matching
+// Pattern
+type RouteBodySize struct {
+	Pattern  string `json:"pattern" example:"/uploads/*"`
+	MaxBytes int64  `json:"max_bytes" example:"26214400"`
+} // @name RouteBodySize
+
+// OrganizationQuotas are the quotas of the plan of an organization and its
+// usage this month. A zero quota is unlimited.
+type OrganizationQuotas struct {
+	OrganizationID       string `json:"organization_id"`
+	Plan                 string `json:"plan" example:"free"`
+	Month                string `json:"month" example:"2026-10"`
+	MaxActiveForms       int64  `json:"max_active_forms" example:"10"`
+	ActiveForms          int64  `json:"active_forms" example:"3"`
+	MaxResponsesPerMonth int64  `json:"max_responses_per_month" example:"1000"`
+	Responses            int64  `json:"responses" example:"42"`
+} // @name OrganizationQuotas
+
+// ExportLimits bound the response exports running at a time
+type ExportLimits struct {
+	MaxConcurrentJobs      int `json:"max_concurrent_jobs" example:"4"`
+	MaxJobsPerOrganization int `json:"max_jobs_per_organization" example:"2"`
+} // @name ExportLimits
+
+// GetLimits godoc
+// @Summary Get my limits
+// @Description The effective limits of the caller: the request rate of the default limit, which overrides set per API key, user or organization, of each tier and of each endpoint limit; the body size bounds of the route policies; and the quotas of the caller's organization with its usage this month and the bounds on its exports. The remaining requests are in the RateLimit-* headers of every rate limited response. Limits are cached for 30 seconds per client.
+// @Tags limits
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Success 200 {object} LimitsResponse
+// @Header 200 {integer} RateLimit-Limit "Requests allowed in the window"
+// @Header 200 {integer} RateLimit-Remaining "Requests left in the window"
+// @Header 200 {integer} RateLimit-Reset "Seconds until the window frees a request"
+// @Failure 401 {string} string "Authentication token is required"
+// @Failure 429 {object} middleware.RateLimitedResponse
+// @Router /api/v1/limits [get]
+func (h *LimitsHandler) GetLimits(c *gin.Context) {
+	client, _, _ := h.limiter.Client(c.Request)
+	orgID, _ := c.Request.Context().Value(middleware.OrganizationIDKey).(string)
+	key := client + " " + orgID
+
+	now := h.now()
+	h.mu.Lock()
+	cached, ok := h.cache[key]
+	h.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		c.JSON(http.StatusOK, cached.limits)
+		return
+	}
+
+	limits := h.limits(c.Request, client)
+	complete := true
+	if _, isKey := c.Request.Context().Value(middleware.APIKeyPrincipalKey).(*apikey.APIKey); !isKey {
+		userID, _ := c.Request.Context().Value(middleware.UserIDKey).(string)
+		quotas, exports, err := h.quotas(c, userID, orgID)
+		if err != nil {
+			// The limits of the gateway are still reported, and the quotas
+			// asked for again next time
+			h.logger.Errorf("Failed to get the quotas of %s: %v", client, err)
+			complete = false
+		} else {
+			limits.Quotas, limits.Exports = quotas, exports
+		}
+	}
+
+	if complete {
+		h.mu.Lock()
+		for k, entry := range h.cache {
+			if !now.Before(entry.expires) {
+				delete(h.cache, k)
+			}
+		}
+		h.cache[key] = cachedLimits{limits: limits, expires: now.Add(limitsCacheTTL)}
+		h.mu.Unlock()
+	}
+	c.JSON(http.StatusOK, limits)
+}
+
+// limits assembles the rate and body size limits of the gateway for client
+func (h *LimitsHandler) limits(r *http.Request, client string) LimitsResponse {
+	cfg := h.limiter.Config()
+	_, override, overridden := h.limiter.Client(r)
+
+	limits := LimitsResponse{
+		Client: client,
+		RateLimit: RateLimits{
+			Enabled:   cfg.Enabled,
+			Default:   window(cfg.RPS, cfg.Window),
+			Tiers:     []TierRateLimit{},
+			Endpoints: []EndpointRateLimit{},
+		},
+		BodySize: BodySizeLimits{
+			DefaultBytes: h.policies.Default().MaxBodyBytes,
+			Routes:       []RouteBodySize{},
+		},
+	}
+	if overridden {
+		limits.RateLimit.Default = window(override.RPS, override.Window)
+		limits.RateLimit.Default.Override = true
+	}
+
+	routes := h.policies.Routes()
+	tiers := make([]string, 0, len(cfg.Tiers))
+	for tier := range cfg.Tiers {
+		tiers = append(tiers, tier)
+	}
+	sort.Strings(tiers)
+	for _, tier := range tiers {
+		limit := TierRateLimit{Name: tier, RateLimitWindow: window(cfg.Tiers[tier].RPS, cfg.Tiers[tier].Window), Routes: []string{}}
+		if h.policies.Default().RateLimitTier == tier {
+			limit.Routes = append(limit.Routes, "*")
+		}
+		for _, route := range routes {
+			if route.RateLimitTier == tier {
+				limit.Routes = append(limit.Routes, route.Pattern)
+			}
+		}
+		limits.RateLimit.Tiers = append(limits.RateLimit.Tiers, limit)
+	}
+
+	patterns := make([]string, 0, len(cfg.Endpoints))
+	for pattern := range cfg.Endpoints {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		limit := cfg.Endpoints[pattern]
+		limits.RateLimit.Endpoints = append(limits.RateLimit.Endpoints, EndpointRateLimit{Pattern: pattern, RateLimitWindow: window(limit.RPS, limit.Window)})
+	}
+
+	for _, route := range routes {
+		if route.MaxBodyBytes != limits.BodySize.DefaultBytes {
+			limits.BodySize.Routes = append(limits.BodySize.Routes, RouteBodySize{Pattern: route.Pattern, MaxBytes: route.MaxBodyBytes})
+		}
+	}
+	return limits
+}
+
+// quotas asks the form service for the quotas of the organization the user
+// acts in
+func (h *LimitsHandler) quotas(c *gin.Context, userID, orgID string) (*OrganizationQuotas, *ExportLimits, error) {
+	if userID == "" {
+		return nil, nil, fmt.Errorf("the caller is not a user")
+	}
+	req, err := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, h.url+"/internal/users/"+url.PathEscape(userID)+"/limits", nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if orgID != "" {
+		req.Header.Set(middleware.OrganizationHeader, orgID)
+	}
+	if requestID := c.GetHeader("X-Request-ID"); requestID != "" {
+		req.Header.Set("X-Request-ID", requestID)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("form service answered %d", resp.StatusCode)
+	}
+
+	var report struct {
+		OrganizationID string `json:"organization_id"`
+		Month          string `json:"month"`
+		Responses      int64  `json:"responses"`
+		ActiveForms    int64  `json:"active_forms"`
+		Plan           struct {
+			Name                 string `json:"name"`
+			MaxActiveForms       int64  `json:"max_active_forms"`
+			MaxResponsesPerMonth int64  `json:"max_responses_per_month"`
+		} `json:"plan"`
+		Exports ExportLimits `json:"exports"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode the quotas: %w", err)
+	}
+	return &OrganizationQuotas{
+		OrganizationID:       report.OrganizationID,
+		Plan:                 report.Plan.Name,
+		Month:                report.Month,
+		MaxActiveForms:       report.Plan.MaxActiveForms,
+		ActiveForms:          report.ActiveForms,
+		MaxResponsesPerMonth: report.Plan.MaxResponsesPerMonth,
+		Responses:            report.Responses,
+	}, &report.Exports, nil
+}
+
+// window reports a limit of limit requests per w
+func window(limit int, w time.Duration) RateLimitWindow {
+	return RateLimitWindow{Limit: limit, WindowSeconds: int(w / time.Second)}
+}
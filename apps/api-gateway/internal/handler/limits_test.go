@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/apikey"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/config"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/policy"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
+)
+
+type limitsFixture struct {
+	h     *LimitsHandler
+	now   time.Time
+	calls int
+	// failing makes the form service answer 503
+	failing bool
+}
+
+func newLimitsFixture(t *testing.T) *limitsFixture {
+	t.Helper()
+	f := &limitsFixture{now: time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.calls++
+		if f.failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Path != "/internal/users/u1/limits" {
+			t.Errorf("asked for %s, want the limits of u1", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"organization_id":"` + r.Header.Get(middleware.OrganizationHeader) + `","month":"2026-10","responses":42,"active_forms":3,` +
+			`"plan":{"name":"team","max_active_forms":50,"max_responses_per_month":10000},` +
+			`"exports":{"max_concurrent_jobs":4,"max_jobs_per_organization":2}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	maxBody, uploadBody := int64(1<<20), int64(25<<20)
+	policies, err := policy.NewResolver(config.PoliciesConfig{
+		Default: config.RoutePolicyConfig{Timeout: 10 * time.Second, MaxBodyBytes: &maxBody,
+			Breaker: config.BreakerPolicyConfig{FailureThreshold: 5, RecoveryTimeout: 30 * time.Second}},
+		Routes: map[string]config.RoutePolicyConfig{
+			"/uploads/*":            {MaxBodyBytes: &uploadBody},
+			"/responses/:id/submit": {RateLimitTier: "submissions"},
+		},
+	}, config.RateLimitConfig{Tiers: map[string]config.EndpointRateLimit{"submissions": {}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	limiter := middleware.NewRateLimiter(config.RateLimitConfig{
+		Enabled:   true,
+		RPS:       100,
+		Window:    time.Minute,
+		Tiers:     map[string]config.EndpointRateLimit{"submissions": {RPS: 20, Window: time.Minute}},
+		Endpoints: map[string]config.EndpointRateLimit{"/api/v1/auth/*": {RPS: 10, Window: time.Minute}},
+		Overrides: map[string]config.EndpointRateLimit{"apikey:k1": {RPS: 1000, Window: time.Minute}},
+	})
+
+	log := logger.New(logger.LogConfig{Level: "fatal", Format: "json", Output: "stderr"})
+	f.h = NewLimitsHandler(limiter, policies, server.URL, time.Second, log)
+	f.h.now = func() time.Time { return f.now }
+	return f
+}
+
+// get asks for the limits of the caller the context values describe
+func (f *limitsFixture) get(t *testing.T, values map[interface{}]interface{}) LimitsResponse {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/limits", nil)
+	ctx := req.Context()
+	for key, value := range values {
+		ctx = context.WithValue(ctx, key, value)
+	}
+	c.Request = req.WithContext(ctx)
+
+	f.h.GetLimits(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET = %d %s, want 200", w.Code, w.Body.String())
+	}
+	var limits LimitsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &limits); err != nil {
+		t.Fatal(err)
+	}
+	return limits
+}
+
+func TestGetLimits(t *testing.T) {
+	f := newLimitsFixture(t)
+	user := map[interface{}]interface{}{middleware.UserIDKey: "u1", middleware.OrganizationIDKey: "acme"}
+
+	limits := f.get(t, user)
+	if limits.Client != "org:acme" || !limits.RateLimit.Enabled {
+		t.Errorf("client = %q, want the organization counted", limits.Client)
+	}
+	if d := limits.RateLimit.Default; d.Limit != 100 || d.WindowSeconds != 60 || d.Override {
+		t.Errorf("default rate limit = %+v, want 100 a minute", d)
+	}
+	if tiers := limits.RateLimit.Tiers; len(tiers) != 1 || tiers[0].Name != "submissions" || tiers[0].Limit != 20 ||
+		len(tiers[0].Routes) != 1 || tiers[0].Routes[0] != "/responses/:id/submit" {
+		t.Errorf("tiers = %+v, want submissions at 20 a minute on its route", tiers)
+	}
+	if endpoints := limits.RateLimit.Endpoints; len(endpoints) != 1 || endpoints[0].Pattern != "/api/v1/auth/*" || endpoints[0].Limit != 10 {
+		t.Errorf("endpoints = %+v, want the auth limit", endpoints)
+	}
+	if body := limits.BodySize; body.DefaultBytes != 1<<20 || len(body.Routes) != 1 || body.Routes[0].MaxBytes != 25<<20 {
+		t.Errorf("body sizes = %+v, want 1 MiB and 25 MiB for uploads", body)
+	}
+	if q := limits.Quotas; q == nil || q.OrganizationID != "acme" || q.Plan != "team" || q.MaxResponsesPerMonth != 10000 || q.Responses != 42 || q.ActiveForms != 3 {
+		t.Errorf("quotas = %+v, want those of the organization acted in", q)
+	}
+	if e := limits.Exports; e == nil || e.MaxConcurrentJobs != 4 || e.MaxJobsPerOrganization != 2 {
+		t.Errorf("exports = %+v, want the export bounds", e)
+	}
+
+	// Served from memory for 30 seconds
+	f.get(t, user)
+	if f.calls != 1 {
+		t.Errorf("form service asked %d times in 30 seconds, want once", f.calls)
+	}
+	f.now = f.now.Add(31 * time.Second)
+	f.get(t, user)
+	if f.calls != 2 {
+		t.Errorf("form service asked %d times after 31 seconds, want again", f.calls)
+	}
+}
+
+func TestGetLimitsOfAPIKeyWithOverride(t *testing.T) {
+	f := newLimitsFixture(t)
+
+	limits := f.get(t, map[interface{}]interface{}{
+		middleware.APIKeyPrincipalKey: &apikey.APIKey{ID: "k1"},
+		middleware.UserIDKey:          "apikey:k1",
+	})
+	if limits.Client != "apikey:k1" {
+		t.Errorf("client = %q, want the key", limits.Client)
+	}
+	if d := limits.RateLimit.Default; d.Limit != 1000 || !d.Override {
+		t.Errorf("default rate limit = %+v, want the override of the key", d)
+	}
+	if limits.Quotas != nil || f.calls != 0 {
+		t.Errorf("quotas = %+v after %d calls, want none for an API key", limits.Quotas, f.calls)
+	}
+}
+
+func TestGetLimitsWithoutFormService(t *testing.T) {
+	f := newLimitsFixture(t)
+	f.failing = true
+	user := map[interface{}]interface{}{middleware.UserIDKey: "u1"}
+
+	// The limits of the gateway are reported without the quotas, which are
+	// asked for again next time
+	if limits := f.get(t, user); limits.Quotas != nil || limits.RateLimit.Default.Limit != 100 {
+		t.Errorf("limits = %+v, want the gateway limits alone", limits)
+	}
+	f.failing = false
+	if limits := f.get(t, user); limits.Quotas == nil || f.calls != 2 {
+		t.Errorf("quotas = %+v after %d calls, want them once the form service is back", limits.Quotas, f.calls)
+	}
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/policy"
+)
+
+// PolicyHandler reports the route policies in effect
+type PolicyHandler struct {
+	resolver *policy.Resolver
+}
+
+// NewPolicyHandler creates a handler reporting the policies of resolver
+func NewPolicyHandler(resolver *policy.Resolver) *PolicyHandler {
+	return &PolicyHandler{resolver: resolver}
+}
+
+// PoliciesResponse lists the effective policies, from the most specific
+// pattern to the least, and the policy of the path asked about
+type PoliciesResponse struct {
+	Default  policy.Policy   `json:"default"`
+	Routes   []policy.Policy `json:"routes"`
+	Path     string          `json:"path,omitempty"`
+	Resolved *policy.Policy  `json:"resolved,omitempty"`
+} // @name PoliciesResponse
+
+// GetPolicies godoc
+// @Summary Get route policies
+// @Description List the effective timeout, retry, circuit breaker, rate limit, body size and cache policies of the gateway routes, with the settings inherited from the default policy filled in
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param path query string false "Gateway path to resolve the policy of, e.g. /forms/123"
+// @Success 200 {object} PoliciesResponse
+// @Router /api/gateway/policies [get]
+func (h *PolicyHandler) GetPolicies(c *gin.Context) {
+	response := PoliciesResponse{
+		Default: h.resolver.Default(),
+		Routes:  h.resolver.Routes(),
+	}
+	if path := c.Query("path"); path != "" {
+		resolved := h.resolver.Resolve(path)
+		response.Path = path
+		response.Resolved = &resolved
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/config"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/policy"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/metrics"
+)
+
+// newPolicyHandler proxies form-service to a service answering with status
+// until it has been called failures times, and 200 afterwards
+func newPolicyHandler(t *testing.T, status int, failures int32) (*Handler, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		w.Write([]byte(`{"id":"form-1"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	log := logger.New(logger.LogConfig{Level: "fatal", Format: "json", Output: "stderr"})
+	h := NewHandler(&config.Config{}, log, metrics.NewCollector(metrics.Config{Enabled: true}), nil)
+	for _, transport := range h.transports {
+		transport.(*retryTransport).backoff = time.Millisecond
+	}
+	service := h.services["form-service"]
+	service.BaseURL = server.URL
+	h.proxies["form-service"] = h.createReverseProxy(service)
+	return h, &hits
+}
+
+func requestWithPolicy(method string, p policy.Policy) *http.Request {
+	r := httptest.NewRequest(method, "/api/v1/forms/form-1", nil)
+	return r.WithContext(policy.NewContext(r.Context(), p))
+}
+
+func TestRouteRetryPolicy(t *testing.T) {
+	retrying := policy.Policy{Pattern: "/forms/:id", Timeout: 5 * time.Second, RetryAttempts: 2,
+		Breaker: policy.Breaker{FailureThreshold: 5, RecoveryTimeout: time.Second}}
+
+	h, hits := newPolicyHandler(t, http.StatusServiceUnavailable, 2)
+	w := httptest.NewRecorder()
+	h.ProxyToService(w, requestWithPolicy(http.MethodGet, retrying), "form-service")
+	if w.Code != http.StatusOK || hits.Load() != 3 {
+		t.Errorf("GET = %d after %d calls, want 200 after 2 retries", w.Code, hits.Load())
+	}
+
+	// The attempts are bounded by the policy
+	h, hits = newPolicyHandler(t, http.StatusServiceUnavailable, 10)
+	w = httptest.NewRecorder()
+	h.ProxyToService(w, requestWithPolicy(http.MethodGet, retrying), "form-service")
+	if w.Code != http.StatusServiceUnavailable || hits.Load() != 3 {
+		t.Errorf("GET = %d after %d calls, want 503 after 3 calls", w.Code, hits.Load())
+	}
+
+	// Requests that are not idempotent are sent once
+	h, hits = newPolicyHandler(t, http.StatusServiceUnavailable, 2)
+	w = httptest.NewRecorder()
+	h.ProxyToService(w, requestWithPolicy(http.MethodPost, retrying), "form-service")
+	if hits.Load() != 1 {
+		t.Errorf("POST was sent %d times, want once", hits.Load())
+	}
+}
+
+func TestRetriesTimedAsUpstream(t *testing.T) {
+	retrying := policy.Policy{Pattern: "/forms/:id", Timeout: 5 * time.Second, RetryAttempts: 2,
+		Breaker: policy.Breaker{FailureThreshold: 5, RecoveryTimeout: time.Second}}
+	h, _ := newPolicyHandler(t, http.StatusServiceUnavailable, 1)
+
+	w := httptest.NewRecorder()
+	traced := middleware.RequestID()(func(w http.ResponseWriter, r *http.Request) {
+		h.ProxyToService(w, r, "form-service")
+	})
+	traced(w, requestWithPolicy(http.MethodGet, retrying))
+	timing := w.Header().Get(middleware.ServerTimingHeader)
+	if w.Code != http.StatusOK || !strings.HasPrefix(timing, "gateway;dur=") || !strings.Contains(timing, ", upstream;dur=") {
+		t.Errorf("GET = %d with Server-Timing %q, want the gateway and upstream times", w.Code, timing)
+	}
+}
+
+func TestRouteCacheTTLPolicy(t *testing.T) {
+	h, _ := newPolicyHandler(t, http.StatusOK, 0)
+	p := policy.Policy{Pattern: "/forms/:id", Timeout: 5 * time.Second, CacheTTL: 30 * time.Second,
+		Breaker: policy.Breaker{FailureThreshold: 5, RecoveryTimeout: time.Second}}
+
+	w := httptest.NewRecorder()
+	h.ProxyToService(w, requestWithPolicy(http.MethodGet, p), "form-service")
+	if got := w.Header().Get("Cache-Control"); got != "private, max-age=30" {
+		t.Errorf("Cache-Control = %q, want the TTL of the route", got)
+	}
+
+	w = httptest.NewRecorder()
+	h.ProxyToService(w, httptest.NewRequest(http.MethodGet, "/api/v1/forms/form-1", nil), "form-service")
+	if got := w.Header().Get("Cache-Control"); got != "" {
+		t.Errorf("Cache-Control = %q without a route policy", got)
+	}
+}
+
+func TestRouteBreakerPolicy(t *testing.T) {
+	h, _ := newPolicyHandler(t, http.StatusOK, 0)
+	strict := policy.Policy{Pattern: "/reports/*", Breaker: policy.Breaker{FailureThreshold: 1, RecoveryTimeout: time.Minute}}
+	lenient := policy.Policy{Pattern: "/forms/:id", Breaker: policy.Breaker{FailureThreshold: 5, RecoveryTimeout: time.Minute}}
+	service := h.services["form-service"]
+
+	reports := h.circuitBreaker(service, requestWithPolicy(http.MethodGet, strict))
+	h.recordFailure(reports)
+	if h.checkCircuitBreaker(reports) {
+		t.Error("the breaker of the route did not open at its threshold")
+	}
+	if forms := h.circuitBreaker(service, requestWithPolicy(http.MethodGet, lenient)); !h.checkCircuitBreaker(forms) {
+		t.Error("a failing route opened the breaker of another route")
+	}
+	if h.circuitBreaker(service, requestWithPolicy(http.MethodGet, strict)) != reports {
+		t.Error("the breaker of a route was not reused")
+	}
+}
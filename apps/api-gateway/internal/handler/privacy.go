@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/audit"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/privacy"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
+)
+
+// PrivacyHandler serves the data subject request endpoints
+type PrivacyHandler struct {
+	requests      *privacy.Service
+	callbackToken string
+	audit         *audit.Recorder
+	logger        logger.Logger
+}
+
+// NewPrivacyHandler creates a new privacy handler. Completions are accepted
+// from callers presenting callbackToken; new requests are recorded to
+// recorder.
+func NewPrivacyHandler(requests *privacy.Service, callbackToken string, recorder *audit.Recorder, logger logger.Logger) *PrivacyHandler {
+	return &PrivacyHandler{
+		requests:      requests,
+		callbackToken: callbackToken,
+		audit:         recorder,
+		logger:        logger,
+	}
+}
+
+// RequestErasure godoc
+// @Summary Erase user data
+// @Description Erase the forms, responses and collaboration history of a user across the services. Erasure is idempotent: while an erasure of the user is pending or completed, that request is returned with 200.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body privacy.Subject true "User to erase"
+// @Success 202 {object} privacy.Request
+// @Success 200 {object} privacy.Request
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/admin/privacy/erasure [post]
+func (h *PrivacyHandler) RequestErasure(c *gin.Context) {
+	h.request(c, h.requests.RequestErasure)
+}
+
+// RequestExport godoc
+// @Summary Export user data
+// @Description Assemble a JSON archive of the forms and submissions of a user. The download link is on the request once it completed.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body privacy.Subject true "User to export"
+// @Success 202 {object} privacy.Request
+// @Success 200 {object} privacy.Request
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/admin/privacy/export [post]
+func (h *PrivacyHandler) RequestExport(c *gin.Context) {
+	h.request(c, h.requests.RequestExport)
+}
+
+func (h *PrivacyHandler) request(c *gin.Context, create func(ctx context.Context, subject privacy.Subject, requestedBy string) (*privacy.Request, bool, error)) {
+	var subject privacy.Subject
+	if err := c.ShouldBindJSON(&subject); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	requestedBy, _ := c.Request.Context().Value(middleware.UserIDKey).(string)
+	req, created, err := create(c.Request.Context(), subject, requestedBy)
+	if err != nil {
+		h.logger.Errorf("Failed to create privacy request: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create privacy request"})
+		return
+	}
+
+	if !created {
+		c.JSON(http.StatusOK, req)
+		return
+	}
+
+	// The request, not the subject, is recorded: it never holds the email
+	event := auditEvent(c, fmt.Sprintf("privacy.%s.requested", req.Kind), "user", req.UserID)
+	event.After = gin.H{"request_id": req.ID, "kind": req.Kind}
+	h.audit.Record(event)
+	c.JSON(http.StatusAccepted, req)
+}
+
+// GetPrivacyRequest godoc
+// @Summary Get privacy request
+// @Description Get the status of an erasure or export request, with what each participant removed or exported as counts
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Request ID"
+// @Success 200 {object} privacy.Request
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/admin/privacy/requests/{id} [get]
+func (h *PrivacyHandler) GetPrivacyRequest(c *gin.Context) {
+	req, err := h.requests.Get(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, privacy.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "privacy request not found"})
+		return
+	}
+	if err != nil {
+		h.logger.Errorf("Failed to get privacy request: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get privacy request"})
+		return
+	}
+
+	c.JSON(http.StatusOK, req)
+}
+
+// CompletePrivacyRequest records the completion of a participant, posted by
+// the event bus with the callback token. It is not routed to clients.
+func (h *PrivacyHandler) CompletePrivacyRequest(c *gin.Context) {
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if h.callbackToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(h.callbackToken)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid callback token"})
+		return
+	}
+
+	var completion privacy.Completion
+	if err := c.ShouldBindJSON(&completion); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	_, err := h.requests.Complete(c.Request.Context(), completion)
+	if errors.Is(err, privacy.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "privacy request not found"})
+		return
+	}
+	if errors.Is(err, privacy.ErrInvalidCompletion) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Errorf("Failed to complete privacy request: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to complete privacy request"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
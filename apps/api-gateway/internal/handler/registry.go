@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/audit"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
+)
+
+// RegistryHandler reports the service registry and pins the health of its
+// instances on behalf of admins
+type RegistryHandler struct {
+	registry *middleware.ServiceRegistry
+	audit    *audit.Recorder
+	logger   logger.Logger
+}
+
+// NewRegistryHandler creates a new registry handler. Health overrides are
+// recorded to recorder.
+func NewRegistryHandler(registry *middleware.ServiceRegistry, recorder *audit.Recorder, logger logger.Logger) *RegistryHandler {
+	return &RegistryHandler{
+		registry: registry,
+		audit:    recorder,
+		logger:   logger,
+	}
+}
+
+// RegistryResponse lists the instances of the registry
+type RegistryResponse struct {
+	Instances []middleware.InstanceStatus `json:"instances"`
+	// Overridden counts the instances under a health override
+	Overridden int `json:"overridden"`
+} // @name RegistryResponse
+
+// SetInstanceHealthRequest pins the health of an instance
+type SetInstanceHealthRequest struct {
+	// Health is healthy or unhealthy to pin the instance, or auto to hand
+	// it back to its health checks
+	Health string `json:"health" binding:"required,oneof=healthy unhealthy auto" example:"unhealthy"`
+	// ExpiresAt ends a pin, after which health checks apply again. A pin
+	// without one lasts until set back to auto.
+	ExpiresAt *time.Time `json:"expires_at"`
+	Reason    string     `json:"reason" binding:"max=500" example:"flapping after deploy"`
+} // @name SetInstanceHealthRequest
+
+// GetRegistry godoc
+// @Summary Get service registry
+// @Description List the service instances the gateway routes to: the health it routes by, the health found by the last health check, when that check ran and the failed checks. Instances under a manual override have overridden set and the override, with who set it and when it expires; their health is the override's whatever the checks find.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} RegistryResponse
+// @Router /api/gateway/registry [get]
+func (h *RegistryHandler) GetRegistry(c *gin.Context) {
+	if err := h.registry.RefreshOverrides(c.Request.Context()); err != nil {
+		h.logger.Warnf("Failed to refresh registry health overrides: %v", err)
+	}
+
+	response := RegistryResponse{Instances: h.registry.Instances()}
+	for _, instance := range response.Instances {
+		if instance.Overridden {
+			response.Overridden++
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// SetInstanceHealth godoc
+// @Summary Override instance health
+// @Description Pin a service instance healthy, to route to it again at once after a fix, or unhealthy, to stop routing to it while it flaps, without waiting for its health checks; auto hands it back to them. A pin lasts until expires_at when set. Overrides are kept in Redis, so they survive restarts, and apply on every replica within registry.refresh_interval.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param instanceId path string true "Instance ID"
+// @Param request body SetInstanceHealthRequest true "Health override"
+// @Success 200 {object} middleware.InstanceStatus
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/gateway/registry/{instanceId}/health [post]
+func (h *RegistryHandler) SetInstanceHealth(c *gin.Context) {
+	var req SetInstanceHealthRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	instanceID := c.Param("instanceId")
+	actor, _ := c.Request.Context().Value(middleware.UserIDKey).(string)
+	before, after, err := h.registry.SetHealthOverride(c.Request.Context(), instanceID, req.Health, req.ExpiresAt, req.Reason, actor)
+	switch {
+	case errors.Is(err, middleware.ErrInvalidOverride):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, middleware.ErrInstanceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		h.logger.Errorf("Failed to override the health of %s: %v", instanceID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to override instance health"})
+		return
+	}
+
+	event := auditEvent(c, "registry.health_overridden", "service_instance", instanceID)
+	event.Before, event.After = before, after
+	h.audit.Record(event)
+	c.JSON(http.StatusOK, after)
+}
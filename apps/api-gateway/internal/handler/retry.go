@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/mtls"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/policy"
+)
+
+// retryBackoff is the wait before the first retry, growing with each attempt
+const retryBackoff = 100 * time.Millisecond
+
+// retryTransport retries idempotent requests without a body that fail to
+// reach a service or find it unavailable, as often as the route policy of the
+// request allows
+type retryTransport struct {
+	next    http.RoundTripper
+	backoff time.Duration
+}
+
+// RoundTrip sends req, retrying it when its policy allows. The time spent,
+// retries included, is reported in the Server-Timing of the response.
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	defer func(start time.Time) {
+		middleware.RecordUpstreamTime(req.Context(), time.Since(start))
+	}(time.Now())
+
+	p, ok := policy.FromContext(req.Context())
+	if !ok || p.RetryAttempts <= 0 || !retryable(req) {
+		return t.next.RoundTrip(req)
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+		if attempt == p.RetryAttempts || !shouldRetry(req.Context(), resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		}
+
+		wait := time.NewTimer(t.backoff * time.Duration(attempt+1))
+		select {
+		case <-req.Context().Done():
+			wait.Stop()
+			return nil, req.Context().Err()
+		case <-wait.C:
+		}
+	}
+}
+
+// CloseIdleConnections closes the idle connections of the wrapped transport
+func (t *retryTransport) CloseIdleConnections() {
+	closeIdleConnections(t.next)
+}
+
+// closeIdleConnections closes the idle connections of transport, if it pools any
+func closeIdleConnections(transport http.RoundTripper) {
+	if closer, ok := transport.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// retryable reports whether sending req again cannot change the outcome
+func retryable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return req.Body == nil || req.Body == http.NoBody
+	}
+	return false
+}
+
+func shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if err != nil {
+		return ctx.Err() == nil && !errors.Is(err, mtls.ErrPlainHTTP)
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
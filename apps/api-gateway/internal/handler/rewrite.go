@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/policy"
+)
+
+// linkHeaders are the response headers holding URLs of other resources
+var linkHeaders = []string{"Location", "Content-Location", "Link"}
+
+// rewriteResponse applies the rewrite rules of a route to a response of the
+// service at serviceURL. Headers are always rewritten; JSON bodies only when
+// they are not compressed and fit the cap, and otherwise streamed untouched.
+func rewriteResponse(resp *http.Response, serviceURL string, rw *policy.Rewrite) error {
+	for name := range resp.Header {
+		if dropHeader(name, rw.DropHeaders) {
+			resp.Header.Del(name)
+		}
+	}
+
+	internal := append([]string{strings.TrimSuffix(serviceURL, "/")}, rw.InternalURLs...)
+	for _, name := range linkHeaders {
+		values := resp.Header.Values(name)
+		for i, value := range values {
+			for _, prefix := range internal {
+				if name == "Link" {
+					// Links are <url>; rel="next", possibly several
+					value = strings.ReplaceAll(value, "<"+prefix, "<"+rw.PublicURL)
+				} else if strings.HasPrefix(value, prefix) {
+					value = rw.PublicURL + strings.TrimPrefix(value, prefix)
+				}
+			}
+			values[i] = value
+		}
+	}
+
+	if len(rw.Body) == 0 || resp.Body == nil || !isJSON(resp.Header.Get("Content-Type")) ||
+		resp.Header.Get("Content-Encoding") != "" || resp.ContentLength > rw.MaxBodyBytes {
+		return nil
+	}
+	// Bodies of unknown length, chunked ones included, are read up to the
+	// cap; past it, what was read is put back in front of the rest
+	body, err := io.ReadAll(io.LimitReader(resp.Body, rw.MaxBodyBytes+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(body)) > rw.MaxBodyBytes {
+		resp.Body = readCloser{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return nil
+	}
+	resp.Body.Close()
+
+	for _, rule := range rw.Body {
+		body = bytes.ReplaceAll(body, jsonStringPrefix(rule.From), jsonStringPrefix(rule.To))
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.TransferEncoding = nil
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return nil
+}
+
+// dropHeader reports whether the header name matches one of patterns
+func dropHeader(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if len(name) >= len(prefix) && strings.EqualFold(name[:len(prefix)], prefix) {
+				return true
+			}
+		} else if strings.EqualFold(name, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
+
+// jsonStringPrefix returns the JSON encoding of a string starting with
+// prefix, up to the closing quote, so that only strings starting with a URL
+// prefix are rewritten
+func jsonStringPrefix(prefix string) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(prefix)
+	return bytes.TrimSuffix(bytes.TrimSuffix(buf.Bytes(), []byte("\n")), []byte(`"`))
+}
+
+// readCloser reads from a reader and closes a body
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
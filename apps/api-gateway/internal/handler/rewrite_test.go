@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/config"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/policy"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/metrics"
+)
+
+func TestRouteRewritePolicy(t *testing.T) {
+	var internalURL string
+	large := `{"url":"http://form-service:8001/api/v1/forms/1","padding":"` + strings.Repeat("x", 256) + `"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Internal-Node", "form-service-7d9f")
+		switch r.URL.Path {
+		case "/api/v1/forms/redirect":
+			w.Header().Set("Link", "<"+internalURL+"/api/v1/forms?page=2>; rel=\"next\", <https://docs.example.com>; rel=\"help\"")
+			http.Redirect(w, r, internalURL+"/api/v1/forms/1", http.StatusFound)
+		case "/api/v1/forms/chunked", "/api/v1/forms/large":
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			body := `{"self":"http://form-service:8001/api/v1/forms/1","note":"see http://form-service:8001/docs"}`
+			if r.URL.Path == "/api/v1/forms/large" {
+				body = large
+			}
+			// Flushing drops the length, so the body is chunked
+			w.Write([]byte(body[:10]))
+			w.(http.Flusher).Flush()
+			w.Write([]byte(body[10:]))
+		case "/api/v1/forms/csv":
+			w.Header().Set("Content-Type", "text/csv")
+			w.Write([]byte("url\nhttp://form-service:8001/api/v1/forms/1\n"))
+		}
+	}))
+	t.Cleanup(server.Close)
+	internalURL = server.URL
+
+	log := logger.New(logger.LogConfig{Level: "fatal", Format: "json", Output: "stderr"})
+	h := NewHandler(&config.Config{}, log, metrics.NewCollector(metrics.Config{Enabled: true}), nil)
+	service := h.services["form-service"]
+	service.BaseURL = server.URL
+	h.proxies["form-service"] = h.createReverseProxy(service)
+
+	rewriting := policy.Policy{Pattern: "/forms/*", Timeout: 5 * time.Second,
+		Breaker: policy.Breaker{FailureThreshold: 5, RecoveryTimeout: time.Second},
+		Rewrite: &policy.Rewrite{
+			PublicURL:    "https://api.example.com",
+			DropHeaders:  []string{"X-Internal-*"},
+			Body:         []policy.URLRewrite{{From: "http://form-service:8001/api/v1", To: "https://api.example.com/api/v1"}},
+			MaxBodyBytes: 200,
+		}}
+	get := func(path string, p *policy.Policy) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		if p != nil {
+			r = r.WithContext(policy.NewContext(r.Context(), *p))
+		}
+		w := httptest.NewRecorder()
+		h.ProxyToService(w, r, "form-service")
+		return w
+	}
+
+	w := get("/api/v1/forms/redirect", &rewriting)
+	if got := w.Header().Get("Location"); w.Code != http.StatusFound || got != "https://api.example.com/api/v1/forms/1" {
+		t.Errorf("redirect = %d to %q, want the public URL", w.Code, got)
+	}
+	if got := w.Header().Get("Link"); got != `<https://api.example.com/api/v1/forms?page=2>; rel="next", <https://docs.example.com>; rel="help"` {
+		t.Errorf("Link = %q, want the internal link rewritten", got)
+	}
+	if got := w.Header().Get("X-Internal-Node"); got != "" {
+		t.Errorf("X-Internal-Node = %q, want it dropped", got)
+	}
+
+	w = get("/api/v1/forms/chunked", &rewriting)
+	if want := `{"self":"https://api.example.com/api/v1/forms/1","note":"see http://form-service:8001/docs"}`; w.Body.String() != want {
+		t.Errorf("chunked body = %s\nwant %s", w.Body.String(), want)
+	}
+
+	// Past the cap, and for other content types, bodies stream untouched
+	if w := get("/api/v1/forms/large", &rewriting); w.Body.String() != large {
+		t.Errorf("body past the cap = %.80s..., want it untouched", w.Body.String())
+	}
+	if w := get("/api/v1/forms/csv", &rewriting); !strings.Contains(w.Body.String(), "http://form-service:8001/") {
+		t.Errorf("CSV body = %q, want it untouched", w.Body.String())
+	}
+
+	// Rewriting is off without the rules
+	w = get("/api/v1/forms/redirect", nil)
+	if got := w.Header().Get("Location"); !strings.HasPrefix(got, server.URL) || w.Header().Get("X-Internal-Node") == "" {
+		t.Errorf("Location = %q, X-Internal-Node = %q without rules, want them untouched", got, w.Header().Get("X-Internal-Node"))
+	}
+}
@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/metrics"
+)
+
+const (
+	// statsCacheTTL is how long totals from downstream services are reused
+	statsCacheTTL = 30 * time.Second
+	// statsTimeout bounds each call to a downstream stats endpoint
+	statsTimeout = 2 * time.Second
+	// statsUnavailable replaces a section whose data could not be collected
+	statsUnavailable = "unavailable"
+	// internalStatsPath is served by every service that reports totals
+	internalStatsPath = "/internal/stats"
+)
+
+// statsSources maps each reported total to the service that owns it: active
+// users, forms, and submitted responses, drafts and tests aside
+var statsSources = map[string]string{
+	"users":     "auth-service",
+	"forms":     "form-service",
+	"responses": "response-service",
+}
+
+// SystemStatsResponse is the admin dashboard schema. Totals that can't be
+// fetched are reported as "unavailable" instead of failing the request.
+type SystemStatsResponse struct {
+	GeneratedAt time.Time              `json:"generated_at"`
+	Totals      map[string]interface{} `json:"totals"`
+	Traffic     TrafficStats           `json:"traffic"`
+	Runtime     RuntimeStats           `json:"runtime"`
+} // @name SystemStatsResponse
+
+// PerformanceMetricsResponse reports gateway traffic over several windows
+type PerformanceMetricsResponse struct {
+	GeneratedAt time.Time               `json:"generated_at"`
+	Windows     map[string]TrafficStats `json:"windows"`
+	Runtime     RuntimeStats            `json:"runtime"`
+} // @name PerformanceMetricsResponse
+
+// TrafficStats holds request rate, error rate and latency percentiles
+type TrafficStats struct {
+	WindowSeconds int `json:"window_seconds"`
+	metrics.WindowStats
+} // @name TrafficStats
+
+// RuntimeStats holds Go runtime statistics of the gateway process
+type RuntimeStats struct {
+	Goroutines     int     `json:"goroutines"`
+	HeapAllocBytes uint64  `json:"heap_alloc_bytes"`
+	HeapSysBytes   uint64  `json:"heap_sys_bytes"`
+	HeapObjects    uint64  `json:"heap_objects"`
+	NumGC          uint32  `json:"num_gc"`
+	GCPauseTotalMs float64 `json:"gc_pause_total_ms"`
+	GCLastPauseMs  float64 `json:"gc_last_pause_ms"`
+	GCMaxPauseMs   float64 `json:"gc_max_recent_pause_ms"`
+} // @name RuntimeStats
+
+// StatsHandler serves the admin statistics endpoints
+type StatsHandler struct {
+	handler *Handler
+	metrics *metrics.Collector
+	client  *http.Client
+
+	mu    sync.Mutex
+	cache map[string]cachedTotal
+}
+
+type cachedTotal struct {
+	value     interface{}
+	fetchedAt time.Time
+}
+
+// NewStatsHandler creates a new stats handler. Downstream service addresses
+// are taken from h.
+func NewStatsHandler(h *Handler, metrics *metrics.Collector) *StatsHandler {
+	return &StatsHandler{
+		handler: h,
+		metrics: metrics,
+		client:  &http.Client{Timeout: statsTimeout},
+		cache:   make(map[string]cachedTotal),
+	}
+}
+
+// GetSystemStats godoc
+// @Summary System statistics
+// @Description Get platform totals, gateway traffic over the last 5 minutes and runtime statistics
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} SystemStatsResponse
+// @Router /api/v1/admin/stats [get]
+func (s *StatsHandler) GetSystemStats(c *gin.Context) {
+	c.JSON(http.StatusOK, SystemStatsResponse{
+		GeneratedAt: time.Now().UTC(),
+		Totals:      s.totals(c.Request.Context()),
+		Traffic:     s.traffic(5 * time.Minute),
+		Runtime:     runtimeStats(),
+	})
+}
+
+// GetPerformanceMetrics godoc
+// @Summary Performance metrics
+// @Description Get gateway request rate, error rate and p50/p95/p99 latency over 1, 5 and 15 minute windows
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} PerformanceMetricsResponse
+// @Router /api/v1/admin/performance [get]
+func (s *StatsHandler) GetPerformanceMetrics(c *gin.Context) {
+	c.JSON(http.StatusOK, PerformanceMetricsResponse{
+		GeneratedAt: time.Now().UTC(),
+		Windows: map[string]TrafficStats{
+			"1m":  s.traffic(time.Minute),
+			"5m":  s.traffic(5 * time.Minute),
+			"15m": s.traffic(15 * time.Minute),
+		},
+		Runtime: runtimeStats(),
+	})
+}
+
+// totals fetches every total concurrently, serving cached values while fresh
+func (s *StatsHandler) totals(ctx context.Context) map[string]interface{} {
+	totals := make(map[string]interface{}, len(statsSources))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for name, service := range statsSources {
+		wg.Add(1)
+		go func(name, service string) {
+			defer wg.Done()
+			value := s.total(ctx, name, service)
+			mu.Lock()
+			totals[name] = value
+			mu.Unlock()
+		}(name, service)
+	}
+	wg.Wait()
+
+	return totals
+}
+
+// total returns one total, or "unavailable" when its service can't be reached
+func (s *StatsHandler) total(ctx context.Context, name, service string) interface{} {
+	s.mu.Lock()
+	cached, ok := s.cache[name]
+	s.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < statsCacheTTL {
+		return cached.value
+	}
+
+	var value interface{} = statsUnavailable
+	if total, err := s.fetchTotal(ctx, service); err != nil {
+		s.handler.logger.Warnf("Failed to fetch %s total from %s: %v", name, service, err)
+	} else {
+		value = total
+	}
+
+	// Failures are cached too so a down service doesn't slow every request
+	s.mu.Lock()
+	s.cache[name] = cachedTotal{value: value, fetchedAt: time.Now()}
+	s.mu.Unlock()
+
+	return value
+}
+
+// fetchTotal calls the internal stats endpoint of a service
+func (s *StatsHandler) fetchTotal(ctx context.Context, serviceName string) (int64, error) {
+	service, ok := s.handler.services[serviceName]
+	if !ok {
+		return 0, fmt.Errorf("service %s is not configured", serviceName)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, statsTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, service.BaseURL+internalStatsPath, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Total int64 `json:"total"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return 0, fmt.Errorf("invalid stats response: %w", err)
+	}
+	return body.Total, nil
+}
+
+func (s *StatsHandler) traffic(window time.Duration) TrafficStats {
+	return TrafficStats{
+		WindowSeconds: int(window.Seconds()),
+		WindowStats:   s.metrics.WindowStats(window),
+	}
+}
+
+// runtimeStats reads the Go runtime statistics of this process
+func runtimeStats() RuntimeStats {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	stats := RuntimeStats{
+		Goroutines:     runtime.NumGoroutine(),
+		HeapAllocBytes: m.HeapAlloc,
+		HeapSysBytes:   m.HeapSys,
+		HeapObjects:    m.HeapObjects,
+		NumGC:          m.NumGC,
+		GCPauseTotalMs: float64(m.PauseTotalNs) / 1e6,
+	}
+	if m.NumGC > 0 {
+		stats.GCLastPauseMs = float64(m.PauseNs[(m.NumGC+255)%256]) / 1e6
+	}
+
+	// PauseNs is a ring of the most recent 256 pauses
+	recent := int(m.NumGC)
+	if recent > len(m.PauseNs) {
+		recent = len(m.PauseNs)
+	}
+	for i := 0; i < recent; i++ {
+		if pause := float64(m.PauseNs[i]) / 1e6; pause > stats.GCMaxPauseMs {
+			stats.GCMaxPauseMs = pause
+		}
+	}
+
+	return stats
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/apikey"
+)
+
+// APIKeyHeader is the header integration clients send their key in
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyPrincipalKey holds the *apikey.APIKey that authenticated the request
+const APIKeyPrincipalKey contextKey = "api_key_principal"
+
+// APIKeyAuth authenticates requests carrying an X-API-Key header and injects
+// the key as the request principal. Requests without the header are passed on
+// untouched so JWT authentication can handle them.
+func APIKeyAuth(keys *apikey.Service) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			secret := r.Header.Get(APIKeyHeader)
+			if secret == "" {
+				next(w, r)
+				return
+			}
+
+			key, err := keys.Authenticate(r.Context(), secret)
+			if errors.Is(err, apikey.ErrInvalidKey) {
+				http.Error(w, "Invalid API key", http.StatusUnauthorized)
+				return
+			}
+			if err != nil {
+				http.Error(w, "API key validation unavailable", http.StatusServiceUnavailable)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), APIKeyPrincipalKey, key)
+			ctx = context.WithValue(ctx, UserAuthenticatedKey, true)
+			ctx = context.WithValue(ctx, UserIDKey, "apikey:"+key.ID)
+			setErrorUser(ctx, "apikey:"+key.ID)
+			next(w, r.WithContext(ctx))
+		}
+	}
+}
+
+// RequireScope limits API key callers to keys holding readScope for safe
+// methods and writeScope for everything else. Requests authenticated with a
+// user token are not affected.
+func RequireScope(readScope, writeScope string) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			key, ok := r.Context().Value(APIKeyPrincipalKey).(*apikey.APIKey)
+			if !ok {
+				next(w, r)
+				return
+			}
+
+			scope := writeScope
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				scope = readScope
+			}
+			if scope == "" || !key.HasScope(scope) {
+				http.Error(w, "API key is missing the required scope", http.StatusForbidden)
+				return
+			}
+
+			next(w, r)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/apikey"
+)
+
+// keyStore is an apikey.Store holding keys in memory. It fails every lookup
+// when down.
+type keyStore struct {
+	apikey.Store
+	keys   map[string]*apikey.APIKey
+	hashes map[string]string
+	down   bool
+}
+
+func (s *keyStore) Save(ctx context.Context, key *apikey.APIKey) error {
+	copied := *key
+	s.keys[key.ID] = &copied
+	return nil
+}
+
+func (s *keyStore) IndexHash(ctx context.Context, hash, id string, ttl time.Duration) error {
+	s.hashes[hash] = id
+	return nil
+}
+
+func (s *keyStore) FindByHash(ctx context.Context, hash string) (*apikey.APIKey, error) {
+	if s.down {
+		return nil, errors.New("connection refused")
+	}
+	id, ok := s.hashes[hash]
+	if !ok {
+		return nil, apikey.ErrNotFound
+	}
+	copied := *s.keys[id]
+	return &copied, nil
+}
+
+func (s *keyStore) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	return nil
+}
+
+// serveScoped serves r behind APIKeyAuth and RequireScope for forms
+func serveScoped(keys *apikey.Service, r *http.Request) (*httptest.ResponseRecorder, string) {
+	var userID string
+	w := httptest.NewRecorder()
+	chain := RequireScope(apikey.ScopeReadForms, apikey.ScopeWriteForms)(func(w http.ResponseWriter, r *http.Request) {
+		userID, _ = r.Context().Value(UserIDKey).(string)
+		w.WriteHeader(http.StatusOK)
+	})
+	APIKeyAuth(keys)(chain)(w, r)
+	return w, userID
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	store := &keyStore{keys: make(map[string]*apikey.APIKey), hashes: make(map[string]string)}
+	keys := apikey.NewService(store, time.Minute)
+	reader, readerSecret, err := keys.Create(context.Background(), apikey.CreateRequest{Name: "reader", Scopes: []string{apikey.ScopeReadForms}}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(method, secret string) *http.Request {
+		r := httptest.NewRequest(method, "/api/v1/forms", nil)
+		if secret != "" {
+			r.Header.Set(APIKeyHeader, secret)
+		}
+		return r
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		secret   string
+		wantCode int
+		wantUser string
+	}{
+		{"read with the read scope", http.MethodGet, readerSecret, http.StatusOK, "apikey:" + reader.ID},
+		{"write without the write scope", http.MethodPost, readerSecret, http.StatusForbidden, ""},
+		{"unknown key", http.MethodGet, "xfk_unknown_secret", http.StatusUnauthorized, ""},
+		{"malformed key", http.MethodGet, "not-a-key", http.StatusUnauthorized, ""},
+		{"no key leaves the request to JWT authentication", http.MethodPost, "", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		w, userID := serveScoped(keys, request(tt.method, tt.secret))
+		if w.Code != tt.wantCode {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.wantCode)
+		}
+		if userID != tt.wantUser {
+			t.Errorf("%s: user = %q, want %q", tt.name, userID, tt.wantUser)
+		}
+	}
+
+	// A store outage is not the caller's fault
+	store.down = true
+	_, secret, _ := keys.Create(context.Background(), apikey.CreateRequest{Name: "fresh", Scopes: []string{apikey.ScopeReadForms}}, "")
+	if w, _ := serveScoped(keys, request(http.MethodGet, secret)); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("store down: status = %d, want 503", w.Code)
+	}
+}
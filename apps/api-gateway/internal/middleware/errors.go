@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"runtime/debug"
+	"sync"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
+	"github.com/Mir00r/X-Form-Backend/shared/errreport"
+)
+
+// ErrorIDHeader carries the ID of the event reported for a failed request
+const ErrorIDHeader = "X-Error-ID"
+
+// errorScopeKey carries the errorScope of a request in its context
+const errorScopeKey contextKey = "error_scope"
+
+// errorScope is the error reporter of a request and the context of the
+// request learnt by the later steps, which Recovery only sees the start of
+type errorScope struct {
+	reporter errreport.Reporter
+
+	mu     sync.Mutex
+	route  string
+	userID string
+}
+
+// SetErrorRoute records the route pattern of the request of ctx for the
+// errors reported for it
+func SetErrorRoute(ctx context.Context, route string) {
+	if scope, ok := ctx.Value(errorScopeKey).(*errorScope); ok {
+		scope.mu.Lock()
+		scope.route = route
+		scope.mu.Unlock()
+	}
+}
+
+// setErrorUser records the caller of the request of ctx once authenticated
+func setErrorUser(ctx context.Context, userID string) {
+	if scope, ok := ctx.Value(errorScopeKey).(*errorScope); ok {
+		scope.mu.Lock()
+		scope.userID = userID
+		scope.mu.Unlock()
+	}
+}
+
+// report reports the event with the context of the request
+func (s *errorScope) report(r *http.Request, event *errreport.Event, status int) string {
+	s.mu.Lock()
+	route, userID := s.route, s.userID
+	s.mu.Unlock()
+	if route == "" {
+		route = r.URL.Path
+	}
+	event.Request = &errreport.Request{
+		Route:         route,
+		Method:        r.Method,
+		Path:          r.URL.Path,
+		Status:        status,
+		CorrelationID: getRequestID(r.Context()),
+		UserID:        userID,
+		Headers:       errreport.SanitizeHeaders(r.Header),
+	}
+	return s.reporter.Report(r.Context(), event)
+}
+
+// ReportError reports an error answered to the request with status and
+// returns the ID of the event, or "" when the request is not behind
+// Recovery
+func ReportError(r *http.Request, err error, status int) string {
+	scope, ok := r.Context().Value(errorScopeKey).(*errorScope)
+	if !ok {
+		return ""
+	}
+	return scope.report(r, &errreport.Event{Err: err}, status)
+}
+
+// Recovery recovers the panics of requests and reports them to the error
+// tracker, answering with the ID of the event as error_id so support can
+// find the event a user reports. The steps after it report their errors
+// with ReportError.
+func Recovery(logger logger.Logger, reporter errreport.Reporter) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			scope := &errorScope{reporter: reporter}
+			r = r.WithContext(context.WithValue(r.Context(), errorScopeKey, scope))
+
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				// The proxy aborts the response of a client that went away
+				if recovered == http.ErrAbortHandler {
+					panic(recovered)
+				}
+
+				requestID := getRequestID(r.Context())
+				id := scope.report(r, &errreport.Event{
+					Err:   errreport.PanicError(recovered),
+					Panic: true,
+					Stack: string(debug.Stack()),
+				}, http.StatusInternalServerError)
+				logger.Errorf("Panic recovered: request_id=%s error_id=%s method=%s path=%s panic=%v",
+					requestID, id, r.Method, r.URL.Path, recovered)
+
+				// Headers already sent can't be replaced
+				if written, ok := w.(interface{ Written() bool }); ok && written.Written() {
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set(ErrorIDHeader, id)
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"error": map[string]interface{}{
+						"code":       "INTERNAL_ERROR",
+						"message":    "Internal server error",
+						"request_id": requestID,
+						"error_id":   id,
+					},
+				})
+			}()
+
+			next(w, r)
+		}
+	}
+}
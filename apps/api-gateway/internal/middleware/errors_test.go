@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/config"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
+	"github.com/Mir00r/X-Form-Backend/shared/errreport"
+)
+
+// newReportingGateway serves behind RequestID, Recovery and authentication
+// as the gateway does
+func newReportingGateway(reporter errreport.Reporter) http.Handler {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ginStep(Recovery(logger.New(logger.LogConfig{Level: "fatal", Format: "json", Output: "stderr"}), reporter)))
+	router.Use(func(c *gin.Context) {
+		SetErrorRoute(c.Request.Context(), c.FullPath())
+		c.Next()
+	})
+	router.Use(ginStep(Authentication(config.JWTConfig{Secret: "secret", Algorithm: "HS256"})))
+
+	router.GET("/api/v1/forms/:id", func(c *gin.Context) { panic("nil form") })
+	router.GET("/api/v1/forms/:id/responses", func(c *gin.Context) {
+		id := ReportError(c.Request, errors.New("connection refused"), http.StatusBadGateway)
+		c.JSON(http.StatusBadGateway, gin.H{"error": gin.H{"code": "SERVICE_UNAVAILABLE", "error_id": id}})
+	})
+	return http.HandlerFunc(RequestID()(router.ServeHTTP))
+}
+
+// userToken is a token of user-1 accepted by newReportingGateway
+func userToken() string {
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": "user-1"}).SignedString([]byte("secret"))
+	return token
+}
+
+func TestRecoveryReportsPanics(t *testing.T) {
+	reporter := errreport.NewNoop()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/forms/f1", nil)
+	req.Header.Set("Authorization", "Bearer "+userToken())
+	req.Header.Set(RequestIDHeader, "req-1")
+	rec := httptest.NewRecorder()
+	newReportingGateway(reporter).ServeHTTP(rec, req)
+
+	events := reporter.Events()
+	if len(events) != 1 {
+		t.Fatalf("reported %d events, want 1", len(events))
+	}
+	event := events[0]
+	if !event.Panic || event.Err.Error() != "panic: nil form" || event.Stack == "" {
+		t.Errorf("event = %+v, want the panic with its stack", event)
+	}
+	r := event.Request
+	if r.Route != "/api/v1/forms/:id" || r.Path != "/api/v1/forms/f1" || r.Status != http.StatusInternalServerError ||
+		r.CorrelationID != "req-1" || r.UserID != "user-1" {
+		t.Errorf("request = %+v", r)
+	}
+	if r.Headers["Authorization"] != "[redacted]" {
+		t.Errorf("Authorization = %q, want it redacted", r.Headers["Authorization"])
+	}
+
+	var body struct {
+		Error struct {
+			Code      string `json:"code"`
+			RequestID string `json:"request_id"`
+			ErrorID   string `json:"error_id"`
+		} `json:"error"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	if rec.Code != http.StatusInternalServerError || body.Error.Code != "INTERNAL_ERROR" ||
+		body.Error.RequestID != "req-1" || body.Error.ErrorID != event.ID || rec.Header().Get(ErrorIDHeader) != event.ID {
+		t.Errorf("response %d %s, want INTERNAL_ERROR with error_id %s", rec.Code, rec.Body, event.ID)
+	}
+}
+
+func TestReportError(t *testing.T) {
+	reporter := errreport.NewNoop()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/forms/f1/responses", nil)
+	req.Header.Set("Authorization", "Bearer "+userToken())
+	rec := httptest.NewRecorder()
+	newReportingGateway(reporter).ServeHTTP(rec, req)
+
+	events := reporter.Events()
+	if len(events) != 1 {
+		t.Fatalf("reported %d events, want 1", len(events))
+	}
+	if r := events[0].Request; r.Route != "/api/v1/forms/:id/responses" || r.Status != http.StatusBadGateway ||
+		r.CorrelationID == "" || r.UserID != "user-1" {
+		t.Errorf("request = %+v", r)
+	}
+
+	if id := ReportError(httptest.NewRequest(http.MethodGet, "/", nil), errors.New("boom"), http.StatusBadGateway); id != "" {
+		t.Errorf("request outside Recovery reported as %q", id)
+	}
+}
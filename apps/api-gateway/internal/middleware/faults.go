@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/faults"
+)
+
+// FaultInjection attaches the fault injection rules matching the request
+// path to the request, for the transport to the service to inject
+func FaultInjection(injector *faults.Injector) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			next(w, injector.Attach(r))
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/shed"
+)
+
+// LoadShedding rejects the requests shedder sheds with 503 and a Retry-After.
+// It must run after authentication, which tells anonymous callers apart.
+func LoadShedding(shedder *shed.Shedder) Middleware {
+	anonymous := func(r *http.Request) bool {
+		authenticated, _ := r.Context().Value(UserAuthenticatedKey).(bool)
+		return !authenticated
+	}
+	return func(next HandlerFunc) HandlerFunc {
+		return shedder.Wrap(http.HandlerFunc(next), anonymous).ServeHTTP
+	}
+}
@@ -0,0 +1,225 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/config"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
+)
+
+// defaultMaintenanceRetryAfter is sent when the operator gave neither an end
+// time nor an explicit retry interval
+const defaultMaintenanceRetryAfter = 300
+
+// MaintenanceState is the maintenance flag shared by all gateway replicas
+type MaintenanceState struct {
+	Enabled    bool       `json:"enabled"`
+	Message    string     `json:"message,omitempty"`
+	StartsAt   *time.Time `json:"starts_at,omitempty"`
+	EndsAt     *time.Time `json:"ends_at,omitempty"`
+	RetryAfter int        `json:"retry_after_seconds,omitempty"`
+	EnabledBy  string     `json:"enabled_by,omitempty"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// Active reports whether maintenance is in effect at the given time, taking
+// the optional scheduled window into account
+func (s MaintenanceState) Active(now time.Time) bool {
+	if !s.Enabled {
+		return false
+	}
+	if s.StartsAt != nil && now.Before(*s.StartsAt) {
+		return false
+	}
+	if s.EndsAt != nil && !now.Before(*s.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// RetryAfterSeconds returns the value of the Retry-After header
+func (s MaintenanceState) RetryAfterSeconds(now time.Time) int {
+	if s.RetryAfter > 0 {
+		return s.RetryAfter
+	}
+	if s.EndsAt != nil {
+		if remaining := int(s.EndsAt.Sub(now).Seconds()); remaining > 0 {
+			return remaining
+		}
+	}
+	return defaultMaintenanceRetryAfter
+}
+
+// MaintenanceStore keeps the maintenance flag in Redis and serves reads from
+// a local copy that is refreshed in the background, so checking the flag on
+// every request never touches the network
+type MaintenanceStore struct {
+	client   *redis.Client
+	key      string
+	interval time.Duration
+	logger   logger.Logger
+
+	mu    sync.RWMutex
+	state MaintenanceState
+}
+
+// NewMaintenanceStore creates a store backed by the Redis instance at cfg.RedisURL
+func NewMaintenanceStore(cfg config.MaintenanceConfig, logger logger.Logger) (*MaintenanceStore, error) {
+	opts, err := redis.ParseURL(cfg.RedisURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Redis URL: %w", err)
+	}
+
+	interval := cfg.RefreshInterval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	key := cfg.Key
+	if key == "" {
+		key = "gateway:maintenance"
+	}
+
+	return &MaintenanceStore{
+		client:   redis.NewClient(opts),
+		key:      key,
+		interval: interval,
+		logger:   logger,
+	}, nil
+}
+
+// Start loads the current flag and keeps the local copy fresh until ctx is cancelled
+func (s *MaintenanceStore) Start(ctx context.Context) {
+	if err := s.Refresh(ctx); err != nil {
+		s.logger.Warnf("Failed to load maintenance state: %v", err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.Refresh(ctx); err != nil {
+					s.logger.Warnf("Failed to refresh maintenance state: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Refresh reloads the flag from Redis. On failure the last known state is kept.
+func (s *MaintenanceStore) Refresh(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	data, err := s.client.Get(ctx, s.key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		s.store(MaintenanceState{})
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var state MaintenanceState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("invalid maintenance state: %w", err)
+	}
+	s.store(state)
+	return nil
+}
+
+// Current returns the locally cached flag
+func (s *MaintenanceStore) Current() MaintenanceState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state
+}
+
+// Set persists a new flag. Other replicas pick it up on their next refresh.
+func (s *MaintenanceStore) Set(ctx context.Context, state MaintenanceState) error {
+	state.UpdatedAt = time.Now().UTC()
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode maintenance state: %w", err)
+	}
+	if err := s.client.Set(ctx, s.key, data, 0).Err(); err != nil {
+		return fmt.Errorf("failed to store maintenance state: %w", err)
+	}
+
+	s.store(state)
+	return nil
+}
+
+// Close closes the Redis connection
+func (s *MaintenanceStore) Close() error {
+	return s.client.Close()
+}
+
+func (s *MaintenanceStore) store(state MaintenanceState) {
+	s.mu.Lock()
+	s.state = state
+	s.mu.Unlock()
+}
+
+// Maintenance rejects traffic with 503 while maintenance is active. Allowlisted
+// paths and callers holding one of the bypass roles are let through.
+func Maintenance(store *MaintenanceStore, maintenanceConfig config.MaintenanceConfig, jwtConfig config.JWTConfig) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			now := time.Now()
+			state := store.Current()
+			if !state.Active(now) {
+				next(w, r)
+				return
+			}
+
+			for _, path := range maintenanceConfig.AllowedPaths {
+				if strings.HasPrefix(r.URL.Path, path) {
+					next(w, r)
+					return
+				}
+			}
+
+			if len(maintenanceConfig.BypassRoles) > 0 {
+				if claims, err := parseTokenClaims(extractToken(r), jwtConfig); err == nil {
+					role := claimString(claims, "role")
+					for _, bypass := range maintenanceConfig.BypassRoles {
+						if role == bypass {
+							next(w, r)
+							return
+						}
+					}
+				}
+			}
+
+			message := state.Message
+			if message == "" {
+				message = "The service is undergoing maintenance. Please try again later."
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Retry-After", strconv.Itoa(state.RetryAfterSeconds(now)))
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":   "service_unavailable",
+				"message": message,
+				"ends_at": state.EndsAt,
+			})
+		}
+	}
+}
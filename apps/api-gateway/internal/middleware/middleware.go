@@ -4,18 +4,25 @@ package middleware
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/apikey"
 	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/config"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/policy"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/routes"
 	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
 	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/metrics"
+	"github.com/Mir00r/X-Form-Backend/shared/flags"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/redis/go-redis/v9"
 )
@@ -34,8 +41,14 @@ const (
 	UserAuthenticatedKey contextKey = "user_authenticated"
 	UserIDKey            contextKey = "user_id"
 	UserRoleKey          contextKey = "user_role"
+	// OrganizationIDKey holds the org_id claim of the caller's token
+	OrganizationIDKey contextKey = "organization_id"
 )
 
+// OrganizationHeader passes the organization of the caller's token to the
+// services. Values sent by clients are dropped, so services can trust it.
+const OrganizationHeader = "X-Organization-ID"
+
 // MiddlewareError represents a middleware-specific error
 type MiddlewareError struct {
 	Code    int
@@ -95,6 +108,11 @@ type ServiceRegistry struct {
 	metrics       *metrics.Collector
 	loadBalancer  *LoadBalancer
 	healthChecker *ServiceHealthChecker
+	// overrides are the health overrides pinned by admins, by instance ID,
+	// persisted to overrideStore when set
+	overrides     map[string]HealthOverride
+	overrideStore OverrideStore
+	now           func() time.Time
 	mutex         sync.RWMutex
 }
 
@@ -109,6 +127,10 @@ type ServiceInstance struct {
 	Metadata  map[string]string `json:"metadata"`
 	Weight    int               `json:"weight"`
 	Tags      []string          `json:"tags"`
+	// FailureCount counts the failed health checks of the instance, and
+	// ConsecutiveFailures those since the last one that passed
+	FailureCount        int `json:"failure_count"`
+	ConsecutiveFailures int `json:"consecutive_failures"`
 }
 
 // ServiceHealth tracks health information for a service
@@ -171,6 +193,8 @@ func NewServiceRegistry(logger logger.Logger, metrics *metrics.Collector) *Servi
 		metrics:       metrics,
 		loadBalancer:  loadBalancer,
 		healthChecker: healthChecker,
+		overrides:     make(map[string]HealthOverride),
+		now:           time.Now,
 	}
 
 	// Initialize with default services
@@ -256,7 +280,9 @@ func (sr *ServiceRegistry) initializeServices() {
 	}
 }
 
-// GetHealthyService returns a healthy service instance using load balancing
+// GetHealthyService returns a healthy service instance using load balancing.
+// Instances under a health override are healthy as the override says,
+// whatever their health checks found.
 func (sr *ServiceRegistry) GetHealthyService(serviceName string) (*ServiceInstance, error) {
 	sr.mutex.RLock()
 	defer sr.mutex.RUnlock()
@@ -267,9 +293,10 @@ func (sr *ServiceRegistry) GetHealthyService(serviceName string) (*ServiceInstan
 	}
 
 	// Filter healthy instances
+	now := sr.now()
 	var healthyInstances []*ServiceInstance
 	for _, instance := range instances {
-		if instance.Health == "healthy" {
+		if health, _ := sr.healthOf(instance, now); health == HealthHealthy {
 			healthyInstances = append(healthyInstances, instance)
 		}
 	}
@@ -304,6 +331,33 @@ func (sr *ServiceRegistry) MarkUnhealthy(instanceID string) {
 	}
 }
 
+// ResetHealth forgets the health verdicts of the instances of the services
+// whose name matches, so they are routed to again and checked at once rather
+// than at the next interval. It returns the number of instances reset.
+func (sr *ServiceRegistry) ResetHealth(match func(service string) bool) int {
+	sr.mutex.Lock()
+	reset := make([]*ServiceInstance, 0)
+	for name, instances := range sr.services {
+		if !match(name) {
+			continue
+		}
+		for _, instance := range instances {
+			instance.Health = "healthy"
+			instance.LastCheck = time.Time{}
+			reset = append(reset, instance)
+		}
+		if health, exists := sr.serviceHealth[name]; exists {
+			*health = ServiceHealth{HealthyInstances: len(instances)}
+		}
+	}
+	sr.mutex.Unlock()
+
+	if len(reset) > 0 {
+		go sr.performBulkHealthCheck()
+	}
+	return len(reset)
+}
+
 // RecordAccess records access to a service instance for load balancing
 func (sr *ServiceRegistry) RecordAccess(instanceID string) {
 	// This could be used for weighted load balancing in the future
@@ -358,6 +412,7 @@ func (sr *ServiceRegistry) startHealthChecker() {
 }
 
 // performBulkHealthCheck performs health checks on all registered services
+// and waits for them
 func (sr *ServiceRegistry) performBulkHealthCheck() {
 	sr.mutex.RLock()
 	allInstances := make([]*ServiceInstance, 0)
@@ -366,18 +421,26 @@ func (sr *ServiceRegistry) performBulkHealthCheck() {
 	}
 	sr.mutex.RUnlock()
 
+	// Overrides are left alone: the checked health is what the instance is
+	// routed by once its override expires or is cleared
+	var wg sync.WaitGroup
 	for _, instance := range allInstances {
+		wg.Add(1)
 		go func(inst *ServiceInstance) {
+			defer wg.Done()
 			healthy, responseTime := sr.healthChecker.CheckHealth(inst)
 
 			sr.mutex.Lock()
+			defer sr.mutex.Unlock()
 			inst.LastCheck = time.Now()
 			if healthy {
-				inst.Health = "healthy"
+				inst.Health = HealthHealthy
+				inst.ConsecutiveFailures = 0
 			} else {
-				inst.Health = "unhealthy"
+				inst.Health = HealthUnhealthy
+				inst.FailureCount++
+				inst.ConsecutiveFailures++
 			}
-			sr.mutex.Unlock()
 
 			// Update health metrics
 			if health, exists := sr.serviceHealth[inst.Name]; exists {
@@ -391,6 +454,7 @@ func (sr *ServiceRegistry) performBulkHealthCheck() {
 			}
 		}(instance)
 	}
+	wg.Wait()
 }
 
 // Step 5: Enhanced Service Discovery Middleware
@@ -530,26 +594,6 @@ func extractServiceName(path string) string {
 	return ""
 }
 
-// RequestID middleware adds a unique request ID to each request
-func RequestID() Middleware {
-	return func(next HandlerFunc) HandlerFunc {
-		return func(w http.ResponseWriter, r *http.Request) {
-			// Check if request ID already exists
-			requestID := r.Header.Get("X-Request-ID")
-			if requestID == "" {
-				// Generate new UUID for request tracking
-				requestID = generateUUID()
-			}
-
-			// Add request ID to context and response header
-			ctx := context.WithValue(r.Context(), RequestIDKey, requestID)
-			w.Header().Set("X-Request-ID", requestID)
-
-			next(w, r.WithContext(ctx))
-		}
-	}
-}
-
 // StructuredLogger middleware provides structured logging with request context
 func StructuredLogger(logger logger.Logger) Middleware {
 	return func(next HandlerFunc) HandlerFunc {
@@ -608,26 +652,6 @@ func Metrics(collector *metrics.Collector) Middleware {
 	}
 }
 
-// Recovery middleware handles panics gracefully
-func Recovery(logger logger.Logger) Middleware {
-	return func(next HandlerFunc) HandlerFunc {
-		return func(w http.ResponseWriter, r *http.Request) {
-			defer func() {
-				if err := recover(); err != nil {
-					requestID := getRequestID(r.Context())
-					logger.Errorf("Panic recovered: request_id=%s method=%s path=%s panic=%v",
-						requestID, r.Method, r.URL.Path, err)
-
-					// Return internal server error
-					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-				}
-			}()
-
-			next(w, r)
-		}
-	}
-}
-
 // CORS middleware handles Cross-Origin Resource Sharing
 func CORS(corsConfig config.CORSConfig) Middleware {
 	return func(next HandlerFunc) HandlerFunc {
@@ -635,7 +659,7 @@ func CORS(corsConfig config.CORSConfig) Middleware {
 			origin := r.Header.Get("Origin")
 
 			// Check if CORS is enabled
-			if !corsConfig.Enabled {
+			if !corsConfig.Enabled || corsHandledUpstream(r) {
 				next(w, r)
 				return
 			}
@@ -836,12 +860,34 @@ func WhitelistValidation(whitelistConfig config.WhitelistConfig) Middleware {
 func Authentication(authConfig config.JWTConfig) Middleware {
 	return func(next HandlerFunc) HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
+			// Only the org_id claim of a verified token names the organization
+			r.Header.Del(OrganizationHeader)
+
 			// Skip authentication for public endpoints
 			if isPublicEndpoint(r.URL.Path) {
 				next(w, r)
 				return
 			}
 
+			// Proxied routes serving anonymous respondents only check a token
+			// when one is sent
+			switch routes.AuthFor(r.Method, r.URL.Path) {
+			case routes.AuthPublic:
+				next(w, r)
+				return
+			case routes.AuthOptional:
+				if extractToken(r) == "" {
+					next(w, r)
+					return
+				}
+			}
+
+			// Already authenticated with an API key
+			if _, ok := r.Context().Value(APIKeyPrincipalKey).(*apikey.APIKey); ok {
+				next(w, r)
+				return
+			}
+
 			// Extract token from request
 			token := extractToken(r)
 			if token == "" {
@@ -849,108 +895,305 @@ func Authentication(authConfig config.JWTConfig) Middleware {
 				return
 			}
 
-			// Basic token validation (simplified)
-			if !validateToken(token, authConfig) {
+			claims, err := parseTokenClaims(token, authConfig)
+			if err != nil {
 				http.Error(w, "Invalid authentication token", http.StatusUnauthorized)
 				return
 			}
 
-			// Add user information to context (simplified)
+			// Add user information to context
+			userID := claimString(claims, "user_id")
+			if userID == "" {
+				userID = claimString(claims, "sub")
+			}
 			ctx := context.WithValue(r.Context(), UserAuthenticatedKey, true)
+			ctx = context.WithValue(ctx, UserIDKey, userID)
+			ctx = context.WithValue(ctx, UserRoleKey, claimString(claims, "role"))
+			setErrorUser(ctx, userID)
+
+			// Tokens acting in an organization name it in org_id; tokens
+			// without the claim act in none
+			subject := flags.Subject{UserID: userID}
+			if orgClaim, ok := claims["org_id"]; ok {
+				orgID, _ := orgClaim.(string)
+				if !isUUID(orgID) {
+					http.Error(w, "Invalid organization claim", http.StatusUnauthorized)
+					return
+				}
+				ctx = context.WithValue(ctx, OrganizationIDKey, orgID)
+				r.Header.Set(OrganizationHeader, orgID)
+				subject.OrganizationID = orgID
+			}
+			ctx = flags.WithSubject(ctx, subject)
 			next(w, r.WithContext(ctx))
 		}
 	}
 }
 
-// Step 4: Rate Limiting Middleware
-func RateLimit(rateLimitConfig config.RateLimitConfig) Middleware {
-	// Redis-based distributed rate limiter with simple fallback
-	globalLimiters := make(map[string]*HybridRateLimiter)
-	endpointLimiters := make(map[string]map[string]*HybridRateLimiter)
+// AdminRequired only lets through requests authenticated with an admin role
+// or an API key with the admin scope. It must run after Authentication.
+func AdminRequired() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if key, ok := r.Context().Value(APIKeyPrincipalKey).(*apikey.APIKey); ok {
+				if !key.HasScope(apikey.ScopeAdmin) {
+					http.Error(w, "Admin scope required", http.StatusForbidden)
+					return
+				}
+				next(w, r)
+				return
+			}
 
+			role, _ := r.Context().Value(UserRoleKey).(string)
+			if role != "admin" && role != "super_admin" {
+				http.Error(w, "Admin role required", http.StatusForbidden)
+				return
+			}
+
+			next(w, r)
+		}
+	}
+}
+
+// Rate limit headers: the IETF draft names, whose reset is the seconds until
+// the window frees a request, and the legacy names, whose reset is the Unix
+// time it does
+const (
+	RateLimitLimitHeader           = "RateLimit-Limit"
+	RateLimitRemainingHeader       = "RateLimit-Remaining"
+	RateLimitResetHeader           = "RateLimit-Reset"
+	LegacyRateLimitLimitHeader     = "X-RateLimit-Limit"
+	LegacyRateLimitRemainingHeader = "X-RateLimit-Remaining"
+	LegacyRateLimitResetHeader     = "X-RateLimit-Reset"
+)
+
+// RateLimitRule is the limit a request is counted against
+type RateLimitRule struct {
+	// Scope is "global", "tier:<name>" or the path of an endpoint limit
+	Scope string
+	// Client is who the request is counted for: "apikey:<id>",
+	// "user:<id>", "org:<id>" or "ip:<address>"
+	Client string
+	Limit  int
+	Window time.Duration
+	// Override is set when an override replaces the global limit of the
+	// client
+	Override bool
+}
+
+// RateLimitStatus is the state of a limit once a request is counted
+type RateLimitStatus struct {
+	Limit     int
+	Remaining int
+	// Reset is when the oldest request counted leaves the window
+	Reset time.Time
+}
+
+// RateLimitedResponse is the body of the responses to throttled requests
+type RateLimitedResponse struct {
+	Error RateLimitedError `json:"error"`
+} // @name RateLimitedResponse
+
+// RateLimitedError tells a throttled client when to retry
+type RateLimitedError struct {
+	Code      string `json:"code" example:"RATE_LIMITED"`
+	Message   string `json:"message" example:"Too many requests"`
+	RequestID string `json:"request_id,omitempty"`
+	// RetryAfter is the seconds until a request is allowed again, as in
+	// the Retry-After header
+	RetryAfter int `json:"retry_after" example:"12"`
+	Limit      int `json:"limit" example:"100"`
+} // @name RateLimitedError
+
+// RateLimiter counts the requests of each client against the limit of their
+// route: the tier of its policy, else the first endpoint limit matching its
+// path, else the global limit, which overrides replace for the API keys,
+// users and organizations they name. It is built once and shared by every
+// request.
+type RateLimiter struct {
+	config   config.RateLimitConfig
+	redisURL string
+	// endpoints are the patterns of the endpoint limits, sorted so the same
+	// one always matches first
+	endpoints []string
+
+	mu       sync.Mutex
+	limiters map[string]*HybridRateLimiter
+}
+
+// NewRateLimiter creates a rate limiter counting in the Redis of cfg, or in
+// memory while Redis is unreachable
+func NewRateLimiter(cfg config.RateLimitConfig) *RateLimiter {
 	// Get Redis URL from config (fallback to env var or local)
-	redisURL := rateLimitConfig.RedisURL
+	redisURL := cfg.RedisURL
 	if redisURL == "" {
 		redisURL = "redis://localhost:6379/0"
 	}
 
-	return func(next HandlerFunc) HandlerFunc {
-		return func(w http.ResponseWriter, r *http.Request) {
-			// Skip if rate limiting is disabled
-			if !rateLimitConfig.Enabled {
-				next(w, r)
-				return
-			}
+	endpoints := make([]string, 0, len(cfg.Endpoints))
+	for pattern := range cfg.Endpoints {
+		endpoints = append(endpoints, pattern)
+	}
+	sort.Strings(endpoints)
 
-			// Get client identifier
-			clientID := getClientIdentifier(r)
-			path := r.URL.Path
+	return &RateLimiter{
+		config:    cfg,
+		redisURL:  redisURL,
+		endpoints: endpoints,
+		limiters:  make(map[string]*HybridRateLimiter),
+	}
+}
 
-			// Check for endpoint-specific rate limits
-			var rps int
-			var window time.Duration
-			var endpointLimit bool
-
-			for pattern, limit := range rateLimitConfig.Endpoints {
-				if matchPath(path, pattern) {
-					rps = limit.RPS
-					window = limit.Window
-					endpointLimit = true
-					break
-				}
-			}
+// Config returns the limits the limiter applies
+func (l *RateLimiter) Config() config.RateLimitConfig {
+	return l.config
+}
 
-			// Use global limits if no endpoint-specific limits found
-			if !endpointLimit {
-				rps = rateLimitConfig.RPS
-				window = rateLimitConfig.Window
-			}
+// Rule returns the limit the request is counted against
+func (l *RateLimiter) Rule(r *http.Request) RateLimitRule {
+	client, override, overridden := l.Client(r)
 
-			// Get or create appropriate rate limiter
-			var limiter *HybridRateLimiter
-			var rateLimitKey string
+	// The tier of the route policy takes the place of the endpoint limits
+	if p, ok := policy.FromContext(r.Context()); ok && p.RateLimitTier != "" {
+		if limit, ok := l.config.Tiers[p.RateLimitTier]; ok {
+			return RateLimitRule{Scope: "tier:" + p.RateLimitTier, Client: client, Limit: limit.RPS, Window: limit.Window}
+		}
+	}
 
-			if endpointLimit {
-				// Use endpoint-specific limiter
-				if _, exists := endpointLimiters[path]; !exists {
-					endpointLimiters[path] = make(map[string]*HybridRateLimiter)
-				}
+	for _, pattern := range l.endpoints {
+		if matchPath(r.URL.Path, pattern) {
+			limit := l.config.Endpoints[pattern]
+			return RateLimitRule{Scope: r.URL.Path, Client: client, Limit: limit.RPS, Window: limit.Window}
+		}
+	}
 
-				if _, exists := endpointLimiters[path][clientID]; !exists {
-					endpointLimiters[path][clientID] = NewHybridRateLimiter(redisURL, window, rps)
-				}
+	if overridden {
+		return RateLimitRule{Scope: "global", Client: client, Limit: override.RPS, Window: override.Window, Override: true}
+	}
+	return RateLimitRule{Scope: "global", Client: client, Limit: l.config.RPS, Window: l.config.Window}
+}
 
-				limiter = endpointLimiters[path][clientID]
-				rateLimitKey = fmt.Sprintf("rate_limit:%s:%s", path, clientID)
-			} else {
-				// Use global limiter
-				if _, exists := globalLimiters[clientID]; !exists {
-					globalLimiters[clientID] = NewHybridRateLimiter(redisURL, window, rps)
-				}
+// Client identifies who the request is counted for, with the override of
+// their global limit. A client named by an override is counted on their own,
+// so a user with an override is not counted with the organization they act
+// in.
+func (l *RateLimiter) Client(r *http.Request) (string, config.EndpointRateLimit, bool) {
+	for _, id := range overridable(r) {
+		if limit, ok := l.config.Overrides[id]; ok {
+			return id, limit, true
+		}
+	}
+	return getClientIdentifier(r), config.EndpointRateLimit{}, false
+}
 
-				limiter = globalLimiters[clientID]
-				rateLimitKey = fmt.Sprintf("rate_limit:global:%s", clientID)
-			}
+// overridable lists the identifiers an override may name for the request,
+// the most specific first
+func overridable(r *http.Request) []string {
+	if key, ok := r.Context().Value(APIKeyPrincipalKey).(*apikey.APIKey); ok {
+		return []string{"apikey:" + key.ID}
+	}
+	var ids []string
+	if userID, _ := r.Context().Value(UserIDKey).(string); userID != "" {
+		ids = append(ids, "user:"+userID)
+	}
+	if orgID, _ := r.Context().Value(OrganizationIDKey).(string); orgID != "" {
+		ids = append(ids, "org:"+orgID)
+	}
+	return ids
+}
 
-			// Check if request is allowed
-			if !limiter.Allow(rateLimitKey) {
-				w.Header().Set("X-RateLimit-Limit", strconv.Itoa(rps))
-				w.Header().Set("X-RateLimit-Remaining", "0")
-				w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(window).Unix(), 10))
-				http.Error(w, "Too many requests", http.StatusTooManyRequests)
+// Take counts the request against its limit, reporting whether it is
+// allowed and the state of the limit
+func (l *RateLimiter) Take(r *http.Request) (RateLimitRule, RateLimitStatus, bool) {
+	rule := l.Rule(r)
+	key := fmt.Sprintf("rate_limit:%s:%s", rule.Scope, rule.Client)
+	status, allowed := l.limiter(key, rule).Take(key)
+	return rule, status, allowed
+}
+
+// limiter returns the limiter of key, created on first use. Creating one
+// connects to Redis, so it is done without holding the lock.
+func (l *RateLimiter) limiter(key string, rule RateLimitRule) *HybridRateLimiter {
+	l.mu.Lock()
+	limiter, ok := l.limiters[key]
+	l.mu.Unlock()
+	if ok {
+		return limiter
+	}
+
+	created := NewHybridRateLimiter(l.redisURL, rule.Window, rule.Limit)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if limiter, ok := l.limiters[key]; ok {
+		created.Close()
+		return limiter
+	}
+	l.limiters[key] = created
+	return created
+}
+
+// Middleware rejects the requests over their limit with 429, telling when
+// to retry. Every limited response carries the state of its limit.
+func (l *RateLimiter) Middleware() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			// Skip if rate limiting is disabled
+			if !l.config.Enabled {
+				next(w, r)
 				return
 			}
 
-			// Add rate limit headers (simplified for Redis-based limiting)
-			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(rps))
-			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(rps/2)) // Approximate remaining
-			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(window).Unix(), 10))
+			_, status, allowed := l.Take(r)
+			SetRateLimitHeaders(w.Header(), status)
+			if allowed {
+				next(w, r)
+				return
+			}
 
-			next(w, r)
+			retryAfter := secondsUntil(status.Reset)
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusTooManyRequests)
+			json.NewEncoder(w).Encode(RateLimitedResponse{Error: RateLimitedError{
+				Code:       "RATE_LIMITED",
+				Message:    "Too many requests",
+				RequestID:  getRequestID(r.Context()),
+				RetryAfter: retryAfter,
+				Limit:      status.Limit,
+			}})
 		}
 	}
 }
 
+// SetRateLimitHeaders sets the draft and legacy rate limit headers of status
+func SetRateLimitHeaders(h http.Header, status RateLimitStatus) {
+	limit := strconv.Itoa(status.Limit)
+	remaining := strconv.Itoa(status.Remaining)
+	h.Set(RateLimitLimitHeader, limit)
+	h.Set(RateLimitRemainingHeader, remaining)
+	h.Set(RateLimitResetHeader, strconv.Itoa(secondsUntil(status.Reset)))
+	h.Set(LegacyRateLimitLimitHeader, limit)
+	h.Set(LegacyRateLimitRemainingHeader, remaining)
+	h.Set(LegacyRateLimitResetHeader, strconv.FormatInt(status.Reset.Unix(), 10))
+}
+
+// secondsUntil returns the whole seconds until t, rounded up, 0 once past
+func secondsUntil(t time.Time) int {
+	until := time.Until(t)
+	if until <= 0 {
+		return 0
+	}
+	return int((until + time.Second - 1) / time.Second)
+}
+
+// Step 4: Rate Limiting Middleware
+func RateLimit(rateLimitConfig config.RateLimitConfig) Middleware {
+	return NewRateLimiter(rateLimitConfig).Middleware()
+}
+
 // Step 6: Circuit Breaker Middleware
 func CircuitBreaker(config config.CircuitBreakerConfig) Middleware {
 	// Advanced circuit breaker with multiple failure modes
@@ -1595,8 +1838,9 @@ func NewRedisRateLimiter(redisURL string, windowSize time.Duration, maxRequests
 	}, nil
 }
 
-// Allow implements sliding window rate limiting using Redis
-func (r *RedisRateLimiter) Allow(key string) (bool, error) {
+// Take implements sliding window rate limiting using Redis. Rejected
+// requests are counted too, so clients retrying early stay limited.
+func (r *RedisRateLimiter) Take(key string) (RateLimitStatus, bool, error) {
 	ctx := context.Background()
 	now := time.Now()
 	windowStart := now.Add(-r.windowSize)
@@ -1607,8 +1851,9 @@ func (r *RedisRateLimiter) Allow(key string) (bool, error) {
 	// Remove expired entries
 	pipe.ZRemRangeByScore(ctx, key, "0", strconv.FormatInt(windowStart.UnixNano(), 10))
 
-	// Count current requests in window
+	// Count current requests in window, and find the oldest
 	countCmd := pipe.ZCard(ctx, key)
+	oldestCmd := pipe.ZRangeWithScores(ctx, key, 0, 0)
 
 	// Add current request timestamp
 	pipe.ZAdd(ctx, key, redis.Z{
@@ -1622,12 +1867,52 @@ func (r *RedisRateLimiter) Allow(key string) (bool, error) {
 	// Execute pipeline
 	_, err := pipe.Exec(ctx)
 	if err != nil {
-		return false, fmt.Errorf("Redis pipeline execution failed: %w", err)
+		return RateLimitStatus{}, false, fmt.Errorf("Redis pipeline execution failed: %w", err)
+	}
+
+	oldest := now
+	if counted := oldestCmd.Val(); len(counted) > 0 {
+		oldest = time.Unix(0, int64(counted[0].Score))
 	}
+	status, allowed := windowStatus(int(countCmd.Val()), r.maxRequests, oldest.Add(r.windowSize))
+	return status, allowed, nil
+}
 
-	// Check if limit exceeded
-	currentCount := countCmd.Val()
-	return currentCount < int64(r.maxRequests), nil
+// windowStatus is the status of a sliding window holding count requests
+// before the current one, reset when the oldest of them leaves it
+func windowStatus(count, maxRequests int, reset time.Time) (RateLimitStatus, bool) {
+	status := RateLimitStatus{Limit: maxRequests, Reset: reset}
+	if count >= maxRequests {
+		return status, false
+	}
+	status.Remaining = maxRequests - count - 1
+	return status, true
+}
+
+// PurgeRateLimits deletes the rate limit counters in Redis whose key, less
+// its rate_limit: prefix, matches pattern, resetting the limits of the
+// clients they count. Keys are walked with SCAN so a large keyspace does
+// not block Redis. It returns the number of counters deleted.
+func PurgeRateLimits(ctx context.Context, client *redis.Client, pattern string) (int, error) {
+	purged := 0
+	var cursor uint64
+	for {
+		keys, next, err := client.Scan(ctx, cursor, "rate_limit:"+pattern, 500).Result()
+		if err != nil {
+			return purged, fmt.Errorf("failed to scan rate limit counters: %w", err)
+		}
+		if len(keys) > 0 {
+			deleted, err := client.Del(ctx, keys...).Result()
+			if err != nil {
+				return purged, fmt.Errorf("failed to delete rate limit counters: %w", err)
+			}
+			purged += int(deleted)
+		}
+		if next == 0 {
+			return purged, nil
+		}
+		cursor = next
+	}
 }
 
 // Close closes the Redis connection
@@ -1639,20 +1924,22 @@ func (r *RedisRateLimiter) Close() error {
 type simpleRateLimiter struct {
 	maxRequests int
 	windowSize  time.Duration
-	requests    []time.Time
+
+	mu       sync.Mutex
+	requests []time.Time
 }
 
 // HybridRateLimiter provides rate limiting with Redis fallback
 type HybridRateLimiter struct {
 	redis    *RedisRateLimiter
 	simple   *simpleRateLimiter
-	useRedis bool
+	useRedis atomic.Bool
 }
 
 // NewHybridRateLimiter creates a new hybrid rate limiter
 func NewHybridRateLimiter(redisURL string, windowSize time.Duration, maxRequests int) *HybridRateLimiter {
 	hybrid := &HybridRateLimiter{
-		simple: newSimpleRateLimiter(maxRequests, maxRequests), // burst = maxRequests
+		simple: newSimpleRateLimiter(maxRequests, windowSize),
 	}
 
 	// Try to initialize Redis
@@ -1660,24 +1947,25 @@ func NewHybridRateLimiter(redisURL string, windowSize time.Duration, maxRequests
 		redisLimiter, err := NewRedisRateLimiter(redisURL, windowSize, maxRequests)
 		if err == nil {
 			hybrid.redis = redisLimiter
-			hybrid.useRedis = true
+			hybrid.useRedis.Store(true)
 		}
 	}
 
 	return hybrid
 }
 
-// Allow checks if the request should be allowed
-func (h *HybridRateLimiter) Allow(key string) bool {
-	if h.useRedis && h.redis != nil {
-		allowed, err := h.redis.Allow(key)
+// Take counts the request, reporting whether it is allowed and the state of
+// the limit
+func (h *HybridRateLimiter) Take(key string) (RateLimitStatus, bool) {
+	if h.useRedis.Load() && h.redis != nil {
+		status, allowed, err := h.redis.Take(key)
 		if err == nil {
-			return allowed
+			return status, allowed
 		}
 		// Fallback to simple limiter if Redis fails
-		h.useRedis = false
+		h.useRedis.Store(false)
 	}
-	return h.simple.Allow()
+	return h.simple.Take()
 }
 
 // Close closes the Redis connection if available
@@ -1688,15 +1976,17 @@ func (h *HybridRateLimiter) Close() error {
 	return nil
 }
 
-func newSimpleRateLimiter(maxRequests, burst int) *simpleRateLimiter {
+func newSimpleRateLimiter(maxRequests int, windowSize time.Duration) *simpleRateLimiter {
 	return &simpleRateLimiter{
 		maxRequests: maxRequests,
-		windowSize:  time.Minute,
+		windowSize:  windowSize,
 		requests:    make([]time.Time, 0),
 	}
 }
 
-func (rl *simpleRateLimiter) Allow() bool {
+func (rl *simpleRateLimiter) Take() (RateLimitStatus, bool) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
 	now := time.Now()
 
 	// Remove old requests outside the window
@@ -1709,25 +1999,23 @@ func (rl *simpleRateLimiter) Allow() bool {
 	}
 	rl.requests = validRequests
 
-	// Check if we can allow this request
-	if len(rl.requests) >= rl.maxRequests {
-		return false
+	oldest := now
+	if len(rl.requests) > 0 {
+		oldest = rl.requests[0]
 	}
+	status, allowed := windowStatus(len(rl.requests), rl.maxRequests, oldest.Add(rl.windowSize))
 
 	// Add this request
-	rl.requests = append(rl.requests, now)
-	return true
+	if allowed {
+		rl.requests = append(rl.requests, now)
+	}
+	return status, allowed
 }
 
 // Utility functions
 
-func generateUUID() string {
-	// Simple UUID generation (in production, use proper UUID library)
-	return fmt.Sprintf("%d", time.Now().UnixNano())
-}
-
 func getRequestID(ctx context.Context) string {
-	if id, ok := ctx.Value("request_id").(string); ok {
+	if id, ok := ctx.Value(RequestIDKey).(string); ok {
 		return id
 	}
 	return ""
@@ -1777,6 +2065,16 @@ func shouldSkipValidation(method, path string) bool {
 	return method == http.MethodGet || method == http.MethodOptions
 }
 
+// corsHandledUpstream reports whether the service answering r decides its
+// CORS headers: embedded forms load and submit from the sites their owners
+// allow, which the gateway does not know
+func corsHandledUpstream(r *http.Request) bool {
+	if strings.HasPrefix(r.URL.Path, "/public/forms/") {
+		return true
+	}
+	return r.URL.Path == "/responses" && (r.Method == http.MethodPost || r.Method == http.MethodOptions)
+}
+
 func isOriginAllowed(origin string, allowedOrigins []string) bool {
 	for _, allowed := range allowedOrigins {
 		if allowed == "*" || allowed == origin {
@@ -1828,9 +2126,19 @@ func extractToken(r *http.Request) string {
 }
 
 func getClientIdentifier(r *http.Request) string {
+	// API keys are limited by key identity
+	if key, ok := r.Context().Value(APIKeyPrincipalKey).(*apikey.APIKey); ok {
+		return fmt.Sprintf("apikey:%s", key.ID)
+	}
+
+	// The members of an organization share its limit
+	if orgID, ok := r.Context().Value(OrganizationIDKey).(string); ok && orgID != "" {
+		return fmt.Sprintf("org:%s", orgID)
+	}
+
 	// Simple client identification
-	if userID := r.Context().Value("user_id"); userID != nil {
-		return fmt.Sprintf("user:%v", userID)
+	if userID, ok := r.Context().Value(UserIDKey).(string); ok && userID != "" {
+		return fmt.Sprintf("user:%s", userID)
 	}
 	return fmt.Sprintf("ip:%s", getClientIPSimple(r))
 }
@@ -1855,6 +2163,16 @@ func validateRequest(r *http.Request, config config.ValidationConfig) error {
 
 // validateToken performs JWT token validation with proper parsing
 func validateToken(token string, config config.JWTConfig) bool {
+	_, err := parseTokenClaims(token, config)
+	return err == nil
+}
+
+// parseTokenClaims verifies the token and returns its claims
+func parseTokenClaims(token string, config config.JWTConfig) (jwt.MapClaims, error) {
+	if token == "" {
+		return nil, fmt.Errorf("token is empty")
+	}
+
 	// Parse and validate JWT token
 	parsedToken, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
 		// Validate the algorithm
@@ -1881,36 +2199,63 @@ func validateToken(token string, config config.JWTConfig) bool {
 	})
 
 	if err != nil {
-		return false
+		return nil, err
 	}
 
 	// Validate token and claims
-	if claims, ok := parsedToken.Claims.(jwt.MapClaims); ok && parsedToken.Valid {
-		// Check expiration
-		if exp, ok := claims["exp"].(float64); ok {
-			if time.Now().Unix() > int64(exp) {
-				return false
-			}
+	claims, ok := parsedToken.Claims.(jwt.MapClaims)
+	if !ok || !parsedToken.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	// Check expiration
+	if exp, ok := claims["exp"].(float64); ok {
+		if time.Now().Unix() > int64(exp) {
+			return nil, fmt.Errorf("token has expired")
 		}
+	}
 
-		// Check not before
-		if nbf, ok := claims["nbf"].(float64); ok {
-			if time.Now().Unix() < int64(nbf) {
-				return false
-			}
+	// Check not before
+	if nbf, ok := claims["nbf"].(float64); ok {
+		if time.Now().Unix() < int64(nbf) {
+			return nil, fmt.Errorf("token is not yet valid")
 		}
+	}
+
+	// Check issued at
+	if iat, ok := claims["iat"].(float64); ok {
+		if time.Now().Unix() < int64(iat) {
+			return nil, fmt.Errorf("token was issued in the future")
+		}
+	}
+
+	return claims, nil
+}
 
-		// Check issued at
-		if iat, ok := claims["iat"].(float64); ok {
-			if time.Now().Unix() < int64(iat) {
+// claimString returns a string claim, or "" when it is absent
+func claimString(claims jwt.MapClaims, name string) string {
+	value, _ := claims[name].(string)
+	return value
+}
+
+// isUUID reports whether s is a UUID in its canonical hyphenated form
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i, c := range s {
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
 				return false
 			}
 		}
-
-		return true
 	}
-
-	return false
+	return s != "00000000-0000-0000-0000-000000000000"
 }
 
 // parseRSAPublicKey parses RSA public key from PEM format
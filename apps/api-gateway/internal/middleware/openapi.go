@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/validator"
+)
+
+// OpenAPIValidation rejects requests whose parameters or JSON body violate
+// the operation they map to in the OpenAPI document. Only requests under one
+// of routeGroups are checked, and requests matching no documented operation
+// fall through to the backend unchanged.
+func OpenAPIValidation(v *validator.OpenAPIValidator, routeGroups []string) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if !inRouteGroup(r.URL.Path, routeGroups) {
+				next(w, r)
+				return
+			}
+
+			errs := v.Validate(r)
+			if len(errs) == 0 {
+				next(w, r)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error": map[string]interface{}{
+					"code":       "VALIDATION_FAILED",
+					"message":    "The request does not match the API specification",
+					"violations": errs,
+					"request_id": r.Header.Get("X-Request-ID"),
+				},
+			})
+		}
+	}
+}
+
+func inRouteGroup(path string, groups []string) bool {
+	for _, group := range groups {
+		group = strings.TrimSuffix(group, "/")
+		if path == group || strings.HasPrefix(path, group+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/policy"
+)
+
+// PolicyResolver attaches the policy of the request path to the request
+// context, where the rate limit and the proxy find it, and enforces its body
+// size limit
+func PolicyResolver(resolver *policy.Resolver) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			p := resolver.Resolve(r.URL.Path)
+
+			if p.MaxBodyBytes > 0 && r.Body != nil && r.Body != http.NoBody {
+				if r.ContentLength > p.MaxBodyBytes {
+					http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+					return
+				}
+				// Bodies sent without a length fail once they outgrow the limit
+				r.Body = http.MaxBytesReader(w, r.Body, p.MaxBodyBytes)
+			}
+
+			next(w, r.WithContext(policy.NewContext(r.Context(), p)))
+		}
+	}
+}
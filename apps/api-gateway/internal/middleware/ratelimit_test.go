@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/apikey"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/config"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/policy"
+)
+
+// testRateLimit counts in memory, the Redis of the tests being unreachable
+func testRateLimit() config.RateLimitConfig {
+	return config.RateLimitConfig{
+		Enabled:   true,
+		RPS:       2,
+		Window:    time.Minute,
+		RedisURL:  "redis://127.0.0.1:1/0",
+		Tiers:     map[string]config.EndpointRateLimit{"reports": {RPS: 1, Window: 30 * time.Second}},
+		Overrides: map[string]config.EndpointRateLimit{"user:vip": {RPS: 5, Window: time.Minute}},
+	}
+}
+
+// limitedRequest is a request of userID, acting in orgID when set
+func limitedRequest(userID, orgID string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/forms", nil)
+	ctx := context.WithValue(r.Context(), UserIDKey, userID)
+	if orgID != "" {
+		ctx = context.WithValue(ctx, OrganizationIDKey, orgID)
+	}
+	return r.WithContext(ctx)
+}
+
+func serveLimited(limiter *RateLimiter, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	limiter.Middleware()(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})(w, r)
+	return w
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	limiter := NewRateLimiter(testRateLimit())
+	headers := []string{
+		RateLimitLimitHeader, RateLimitRemainingHeader, RateLimitResetHeader,
+		LegacyRateLimitLimitHeader, LegacyRateLimitRemainingHeader, LegacyRateLimitResetHeader,
+	}
+
+	// Allowed responses count down the requests remaining
+	for i, remaining := range []string{"1", "0"} {
+		w := serveLimited(limiter, limitedRequest("ada", ""))
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d = %d, want 200", i+1, w.Code)
+		}
+		for _, header := range headers {
+			if w.Header().Get(header) == "" {
+				t.Errorf("request %d: %s missing from an allowed response", i+1, header)
+			}
+		}
+		if got := w.Header().Get(RateLimitLimitHeader); got != "2" {
+			t.Errorf("request %d: RateLimit-Limit = %s, want 2", i+1, got)
+		}
+		if got := w.Header().Get(RateLimitRemainingHeader); got != remaining {
+			t.Errorf("request %d: RateLimit-Remaining = %s, want %s", i+1, got, remaining)
+		}
+		if got := w.Header().Get(LegacyRateLimitRemainingHeader); got != remaining {
+			t.Errorf("request %d: X-RateLimit-Remaining = %s, want %s", i+1, got, remaining)
+		}
+		if reset, _ := strconv.Atoi(w.Header().Get(RateLimitResetHeader)); reset < 59 || reset > 60 {
+			t.Errorf("request %d: RateLimit-Reset = %d, want the seconds until the window frees a request", i+1, reset)
+		}
+		if reset, _ := strconv.ParseInt(w.Header().Get(LegacyRateLimitResetHeader), 10, 64); reset < time.Now().Unix()+59 {
+			t.Errorf("request %d: X-RateLimit-Reset = %d, want the Unix time the window frees a request", i+1, reset)
+		}
+	}
+
+	// Throttled responses tell when to retry, in the headers and the body
+	w := serveLimited(limiter, limitedRequest("ada", ""))
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("third request = %d, want 429", w.Code)
+	}
+	for _, header := range append(headers, "Retry-After") {
+		if w.Header().Get(header) == "" {
+			t.Errorf("%s missing from a throttled response", header)
+		}
+	}
+	if got := w.Header().Get(RateLimitRemainingHeader); got != "0" {
+		t.Errorf("throttled RateLimit-Remaining = %s, want 0", got)
+	}
+	var body RateLimitedResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("throttled body %q: %v", w.Body.String(), err)
+	}
+	retryAfter, _ := strconv.Atoi(w.Header().Get("Retry-After"))
+	if body.Error.Code != "RATE_LIMITED" || body.Error.RetryAfter != retryAfter || retryAfter < 1 || body.Error.Limit != 2 {
+		t.Errorf("throttled body = %+v, want the Retry-After of %d seconds", body.Error, retryAfter)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("throttled Content-Type = %q, want JSON", got)
+	}
+
+	// Other clients have their own count
+	if w := serveLimited(limiter, limitedRequest("grace", "")); w.Code != http.StatusOK {
+		t.Errorf("another user = %d, want 200", w.Code)
+	}
+}
+
+func TestRateLimitRules(t *testing.T) {
+	limiter := NewRateLimiter(testRateLimit())
+
+	for _, tc := range []struct {
+		name  string
+		r     *http.Request
+		want  RateLimitRule
+		count int
+	}{
+		{"user", limitedRequest("ada", ""), RateLimitRule{Scope: "global", Client: "user:ada", Limit: 2, Window: time.Minute}, 2},
+		{"organization member", limitedRequest("ada", "acme"), RateLimitRule{Scope: "global", Client: "org:acme", Limit: 2, Window: time.Minute}, 2},
+		// An override counts its client on their own, even in an organization
+		{"user with an override", limitedRequest("vip", "acme"), RateLimitRule{Scope: "global", Client: "user:vip", Limit: 5, Window: time.Minute, Override: true}, 5},
+	} {
+		if got := limiter.Rule(tc.r); got != tc.want {
+			t.Errorf("%s: rule = %+v, want %+v", tc.name, got, tc.want)
+		}
+		for i := 0; i < tc.count; i++ {
+			if _, _, allowed := limiter.Take(tc.r); !allowed {
+				t.Errorf("%s: request %d throttled, want %d allowed", tc.name, i+1, tc.count)
+			}
+		}
+		if _, _, allowed := limiter.Take(tc.r); allowed {
+			t.Errorf("%s: request %d allowed, want it throttled", tc.name, tc.count+1)
+		}
+	}
+
+	// The tier of the route policy applies over the override
+	r := limitedRequest("vip", "")
+	r = r.WithContext(policy.NewContext(r.Context(), policy.Policy{RateLimitTier: "reports"}))
+	want := RateLimitRule{Scope: "tier:reports", Client: "user:vip", Limit: 1, Window: 30 * time.Second}
+	if got := limiter.Rule(r); got != want {
+		t.Errorf("tiered rule = %+v, want %+v", got, want)
+	}
+
+	// API keys are counted by key
+	r = httptest.NewRequest(http.MethodGet, "/forms", nil)
+	r = r.WithContext(context.WithValue(r.Context(), APIKeyPrincipalKey, &apikey.APIKey{ID: "k1"}))
+	if got := limiter.Rule(r); got.Client != "apikey:k1" {
+		t.Errorf("API key counted for %q, want apikey:k1", got.Client)
+	}
+}
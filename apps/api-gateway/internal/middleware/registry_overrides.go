@@ -0,0 +1,281 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Health of service instances, and the health overrides admins pin
+const (
+	HealthHealthy   = "healthy"
+	HealthUnhealthy = "unhealthy"
+	// HealthAuto clears an override, handing the instance back to its
+	// health checks
+	HealthAuto = "auto"
+)
+
+// Errors of health overrides
+var (
+	ErrInstanceNotFound = errors.New("service instance not found")
+	ErrInvalidOverride  = errors.New("invalid health override")
+)
+
+// HealthOverride pins the health of a service instance, whatever its health
+// checks find, until ExpiresAt when set
+type HealthOverride struct {
+	InstanceID string     `json:"instance_id" example:"form-service-1"`
+	Health     string     `json:"health" example:"unhealthy"`
+	Reason     string     `json:"reason,omitempty" example:"flapping after deploy"`
+	SetBy      string     `json:"set_by,omitempty"`
+	SetAt      time.Time  `json:"set_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+} // @name HealthOverride
+
+// Expired reports whether the override no longer applies at now
+func (o HealthOverride) Expired(now time.Time) bool {
+	return o.ExpiresAt != nil && !now.Before(*o.ExpiresAt)
+}
+
+// OverrideStore persists the health overrides, so they outlive restarts and
+// apply on every replica
+type OverrideStore interface {
+	// Load returns the overrides stored, expired ones included, by instance ID
+	Load(ctx context.Context) (map[string]HealthOverride, error)
+	Save(ctx context.Context, override HealthOverride) error
+	Delete(ctx context.Context, instanceID string) error
+}
+
+// RedisOverrideStore keeps the health overrides in a Redis hash, by instance
+// ID
+type RedisOverrideStore struct {
+	client *redis.Client
+	key    string
+}
+
+// NewRedisOverrideStore creates a store keeping the overrides in the hash key
+func NewRedisOverrideStore(client *redis.Client, key string) *RedisOverrideStore {
+	return &RedisOverrideStore{client: client, key: key}
+}
+
+// Load returns the overrides of the hash
+func (s *RedisOverrideStore) Load(ctx context.Context) (map[string]HealthOverride, error) {
+	fields, err := s.client.HGetAll(ctx, s.key).Result()
+	if err != nil {
+		return nil, err
+	}
+	overrides := make(map[string]HealthOverride, len(fields))
+	for instanceID, data := range fields {
+		var override HealthOverride
+		if err := json.Unmarshal([]byte(data), &override); err != nil {
+			return nil, fmt.Errorf("invalid health override of %s: %w", instanceID, err)
+		}
+		overrides[instanceID] = override
+	}
+	return overrides, nil
+}
+
+// Save stores override, replacing the one of its instance
+func (s *RedisOverrideStore) Save(ctx context.Context, override HealthOverride) error {
+	data, err := json.Marshal(override)
+	if err != nil {
+		return fmt.Errorf("failed to encode health override: %w", err)
+	}
+	return s.client.HSet(ctx, s.key, override.InstanceID, data).Err()
+}
+
+// Delete removes the override of an instance
+func (s *RedisOverrideStore) Delete(ctx context.Context, instanceID string) error {
+	return s.client.HDel(ctx, s.key, instanceID).Err()
+}
+
+// InstanceStatus is a service instance as the registry routes to it
+type InstanceStatus struct {
+	ServiceInstance
+	// CheckedHealth is the health found by the last health check, which
+	// Health follows unless the instance is under an override
+	CheckedHealth string `json:"checked_health" example:"healthy"`
+	// Overridden is set while an override pins Health
+	Overridden bool            `json:"overridden"`
+	Override   *HealthOverride `json:"override,omitempty"`
+} // @name InstanceStatus
+
+// SyncOverrides loads the health overrides of store and reloads them every
+// interval until ctx is done, so overrides set on other replicas apply here
+// too. Overrides set through the registry are saved to store.
+func (sr *ServiceRegistry) SyncOverrides(ctx context.Context, store OverrideStore, interval time.Duration) {
+	sr.mutex.Lock()
+	sr.overrideStore = store
+	sr.mutex.Unlock()
+
+	if err := sr.RefreshOverrides(ctx); err != nil {
+		sr.logger.Warnf("Failed to load registry health overrides: %v", err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := sr.RefreshOverrides(ctx); err != nil {
+					sr.logger.Warnf("Failed to refresh registry health overrides: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// RefreshOverrides reloads the overrides from the store. On failure the last
+// known overrides are kept. Expired overrides are dropped; they stay in the
+// store until their instance is overridden again or set back to auto.
+func (sr *ServiceRegistry) RefreshOverrides(ctx context.Context) error {
+	sr.mutex.RLock()
+	store := sr.overrideStore
+	sr.mutex.RUnlock()
+	if store == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	overrides, err := store.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	sr.mutex.Lock()
+	defer sr.mutex.Unlock()
+	now := sr.now()
+	for instanceID, override := range overrides {
+		if override.Expired(now) {
+			delete(overrides, instanceID)
+		}
+	}
+	for instanceID, override := range sr.overrides {
+		if _, kept := overrides[instanceID]; !kept && override.Expired(now) {
+			sr.logger.Infof("Health override of %s expired; health checks apply again", instanceID)
+		}
+	}
+	sr.overrides = overrides
+	return nil
+}
+
+// Instances returns the status of every instance, by service then ID
+func (sr *ServiceRegistry) Instances() []InstanceStatus {
+	sr.mutex.RLock()
+	defer sr.mutex.RUnlock()
+
+	now := sr.now()
+	statuses := make([]InstanceStatus, 0)
+	for _, instances := range sr.services {
+		for _, instance := range instances {
+			statuses = append(statuses, sr.statusOf(instance, now))
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Name != statuses[j].Name {
+			return statuses[i].Name < statuses[j].Name
+		}
+		return statuses[i].ID < statuses[j].ID
+	})
+	return statuses
+}
+
+// SetHealthOverride pins the health of an instance to healthy or unhealthy,
+// until expiresAt when set, or with auto clears its override. The override
+// is saved to the store before it applies. The status of the instance before
+// and after the change is returned.
+func (sr *ServiceRegistry) SetHealthOverride(ctx context.Context, instanceID, health string, expiresAt *time.Time, reason, actor string) (before, after InstanceStatus, err error) {
+	switch health {
+	case HealthHealthy, HealthUnhealthy:
+		if expiresAt != nil && !expiresAt.After(sr.now()) {
+			return before, after, fmt.Errorf("%w: expires_at must be in the future", ErrInvalidOverride)
+		}
+	case HealthAuto:
+		if expiresAt != nil {
+			return before, after, fmt.Errorf("%w: auto takes no expires_at", ErrInvalidOverride)
+		}
+	default:
+		return before, after, fmt.Errorf("%w: health %q, want healthy, unhealthy or auto", ErrInvalidOverride, health)
+	}
+
+	sr.mutex.RLock()
+	instance := sr.instance(instanceID)
+	store := sr.overrideStore
+	if instance != nil {
+		before = sr.statusOf(instance, sr.now())
+	}
+	sr.mutex.RUnlock()
+	if instance == nil {
+		return before, after, fmt.Errorf("%w: %s", ErrInstanceNotFound, instanceID)
+	}
+
+	override := HealthOverride{
+		InstanceID: instanceID,
+		Health:     health,
+		Reason:     reason,
+		SetBy:      actor,
+		SetAt:      sr.now().UTC(),
+		ExpiresAt:  expiresAt,
+	}
+	if store != nil {
+		if health == HealthAuto {
+			err = store.Delete(ctx, instanceID)
+		} else {
+			err = store.Save(ctx, override)
+		}
+		if err != nil {
+			return before, after, fmt.Errorf("failed to store health override: %w", err)
+		}
+	}
+
+	sr.mutex.Lock()
+	if health == HealthAuto {
+		delete(sr.overrides, instanceID)
+	} else {
+		sr.overrides[instanceID] = override
+	}
+	after = sr.statusOf(instance, sr.now())
+	sr.mutex.Unlock()
+
+	sr.logger.Warnf("Health of instance %s set to %s by %s", instanceID, health, actor)
+	return before, after, nil
+}
+
+// instance returns the instance of an ID, or nil. Callers hold the mutex.
+func (sr *ServiceRegistry) instance(instanceID string) *ServiceInstance {
+	for _, instances := range sr.services {
+		for _, instance := range instances {
+			if instance.ID == instanceID {
+				return instance
+			}
+		}
+	}
+	return nil
+}
+
+// healthOf returns the health instance is routed by at now, and the override
+// setting it if any. Callers hold the mutex.
+func (sr *ServiceRegistry) healthOf(instance *ServiceInstance, now time.Time) (string, *HealthOverride) {
+	if override, ok := sr.overrides[instance.ID]; ok && !override.Expired(now) {
+		return override.Health, &override
+	}
+	return instance.Health, nil
+}
+
+// statusOf returns the status of instance at now. Callers hold the mutex.
+func (sr *ServiceRegistry) statusOf(instance *ServiceInstance, now time.Time) InstanceStatus {
+	status := InstanceStatus{ServiceInstance: *instance, CheckedHealth: instance.Health}
+	status.Health, status.Override = sr.healthOf(instance, now)
+	status.Overridden = status.Override != nil
+	return status
+}
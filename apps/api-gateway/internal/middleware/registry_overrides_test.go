@@ -0,0 +1,216 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
+)
+
+// memoryOverrideStore keeps overrides in memory, as the Redis hash does
+type memoryOverrideStore struct {
+	mu        sync.Mutex
+	overrides map[string]HealthOverride
+}
+
+func (s *memoryOverrideStore) Load(context.Context) (map[string]HealthOverride, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	overrides := make(map[string]HealthOverride, len(s.overrides))
+	for id, override := range s.overrides {
+		overrides[id] = override
+	}
+	return overrides, nil
+}
+
+func (s *memoryOverrideStore) Save(_ context.Context, override HealthOverride) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.overrides == nil {
+		s.overrides = make(map[string]HealthOverride)
+	}
+	s.overrides[override.InstanceID] = override
+	return nil
+}
+
+func (s *memoryOverrideStore) Delete(_ context.Context, instanceID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.overrides, instanceID)
+	return nil
+}
+
+// healthEndpoint is the /health of an instance, failing while down is set
+type healthEndpoint struct {
+	*httptest.Server
+	down atomic.Bool
+}
+
+func newHealthEndpoint(t *testing.T) *healthEndpoint {
+	t.Helper()
+	endpoint := &healthEndpoint{}
+	endpoint.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if endpoint.down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(endpoint.Close)
+	return endpoint
+}
+
+// instance returns the form-service instance of id checked at the endpoint
+func (e *healthEndpoint) instance(t *testing.T, id string) *ServiceInstance {
+	t.Helper()
+	u, err := url.Parse(e.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, _ := strconv.Atoi(u.Port())
+	return &ServiceInstance{ID: id, Name: "form-service", Host: u.Hostname(), Port: port, Health: HealthHealthy}
+}
+
+// newTestRegistry creates a registry of instances, without the background
+// health checker
+func newTestRegistry(instances ...*ServiceInstance) *ServiceRegistry {
+	config := &ServiceRegistryConfig{HealthCheckTimeout: time.Second}
+	sr := &ServiceRegistry{
+		services:      make(map[string][]*ServiceInstance),
+		serviceHealth: make(map[string]*ServiceHealth),
+		config:        config,
+		logger:        logger.New(logger.LogConfig{Level: "fatal", Format: "json", Output: "stderr"}),
+		loadBalancer:  &LoadBalancer{counters: make(map[string]int)},
+		healthChecker: &ServiceHealthChecker{client: &http.Client{Timeout: config.HealthCheckTimeout}, config: config},
+		overrides:     make(map[string]HealthOverride),
+		now:           time.Now,
+	}
+	for _, instance := range instances {
+		sr.services[instance.Name] = append(sr.services[instance.Name], instance)
+		sr.serviceHealth[instance.Name] = &ServiceHealth{}
+	}
+	return sr
+}
+
+func TestHealthOverrideExpires(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	store := &memoryOverrideStore{}
+	registry := newTestRegistry(newHealthEndpoint(t).instance(t, "form-service-1"))
+	registry.now = func() time.Time { return now }
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	registry.SyncOverrides(ctx, store, time.Hour)
+
+	expiresAt := now.Add(10 * time.Minute)
+	before, after, err := registry.SetHealthOverride(ctx, "form-service-1", HealthUnhealthy, &expiresAt, "flapping", "admin-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before.Overridden || !after.Overridden || after.Health != HealthUnhealthy || after.CheckedHealth != HealthHealthy || after.Override.SetBy != "admin-1" {
+		t.Errorf("override changed %+v into %+v", before, after)
+	}
+	if _, err := registry.GetHealthyService("form-service"); err == nil {
+		t.Error("an instance pinned unhealthy is routed to")
+	}
+
+	// A restarted replica loads the override from the store
+	restarted := newTestRegistry(newHealthEndpoint(t).instance(t, "form-service-1"))
+	restarted.now = registry.now
+	restarted.SyncOverrides(ctx, store, time.Hour)
+	if _, err := restarted.GetHealthyService("form-service"); err == nil {
+		t.Error("the override was lost on restart")
+	}
+
+	// Once it expires the health checks apply again
+	now = expiresAt
+	if _, err := registry.GetHealthyService("form-service"); err != nil {
+		t.Errorf("an instance whose override expired is not routed to: %v", err)
+	}
+	if err := restarted.RefreshOverrides(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if status := restarted.Instances()[0]; status.Overridden || status.Health != HealthHealthy {
+		t.Errorf("instance after its override expired = %+v", status)
+	}
+
+	past := now.Add(-time.Minute)
+	for _, tt := range []struct {
+		instanceID, health string
+		expiresAt          *time.Time
+		want               error
+	}{
+		{"form-service-9", HealthUnhealthy, nil, ErrInstanceNotFound},
+		{"form-service-1", "degraded", nil, ErrInvalidOverride},
+		{"form-service-1", HealthHealthy, &past, ErrInvalidOverride},
+		{"form-service-1", HealthAuto, &expiresAt, ErrInvalidOverride},
+	} {
+		if _, _, err := registry.SetHealthOverride(ctx, tt.instanceID, tt.health, tt.expiresAt, "", "admin-1"); !errors.Is(err, tt.want) {
+			t.Errorf("%s %s: err = %v, want %v", tt.instanceID, tt.health, err, tt.want)
+		}
+	}
+}
+
+func TestHealthOverrideOutlastsHealthChecks(t *testing.T) {
+	endpoint := newHealthEndpoint(t)
+	store := &memoryOverrideStore{}
+	registry := newTestRegistry(endpoint.instance(t, "form-service-1"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	registry.SyncOverrides(ctx, store, time.Hour)
+	routed := func() bool {
+		_, err := registry.GetHealthyService("form-service")
+		return err == nil
+	}
+
+	endpoint.down.Store(true)
+	registry.performBulkHealthCheck()
+	if routed() {
+		t.Fatal("an instance failing its health check is routed to")
+	}
+
+	// Pinned healthy, it is routed to whatever the checks find
+	if _, _, err := registry.SetHealthOverride(ctx, "form-service-1", HealthHealthy, nil, "fixed", "admin-1"); err != nil {
+		t.Fatal(err)
+	}
+	registry.performBulkHealthCheck()
+	if !routed() {
+		t.Error("an instance pinned healthy is not routed to after a failed check")
+	}
+	status := registry.Instances()[0]
+	if !status.Overridden || status.Health != HealthHealthy || status.CheckedHealth != HealthUnhealthy ||
+		status.FailureCount != 2 || status.ConsecutiveFailures != 2 || status.LastCheck.IsZero() {
+		t.Errorf("pinned instance = %+v", status)
+	}
+
+	// Pinned unhealthy, passing checks do not bring it back
+	endpoint.down.Store(false)
+	if _, _, err := registry.SetHealthOverride(ctx, "form-service-1", HealthUnhealthy, nil, "", "admin-1"); err != nil {
+		t.Fatal(err)
+	}
+	registry.performBulkHealthCheck()
+	if routed() {
+		t.Error("an instance pinned unhealthy is routed to after a passed check")
+	}
+
+	// auto hands it back to the checks, on every replica
+	if _, after, err := registry.SetHealthOverride(ctx, "form-service-1", HealthAuto, nil, "", "admin-1"); err != nil || after.Overridden {
+		t.Fatalf("auto left %+v (%v)", after, err)
+	}
+	if !routed() {
+		t.Error("an instance set back to auto is not routed to after a passed check")
+	}
+	if status := registry.Instances()[0]; status.ConsecutiveFailures != 0 || status.FailureCount != 2 {
+		t.Errorf("failures after a passed check = %d consecutive of %d", status.ConsecutiveFailures, status.FailureCount)
+	}
+	if overrides, _ := store.Load(ctx); len(overrides) != 0 {
+		t.Errorf("auto left %v in the store", overrides)
+	}
+}
@@ -0,0 +1,226 @@
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Headers identifying and timing a request, set on every response
+const (
+	RequestIDHeader         = "X-Request-ID"
+	UpstreamRequestIDHeader = "X-Upstream-Request-ID"
+	TraceparentHeader       = "traceparent"
+	ServerTimingHeader      = "Server-Timing"
+)
+
+// requestTimingKey carries the requestTiming of a request in its context
+const requestTimingKey contextKey = "request_timing"
+
+// requestIDPattern is the form of the request IDs taken from clients; others
+// are replaced, as the ID ends up in logs and in the headers of services
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// requestTiming accumulates the time a request spent waiting on services
+type requestTiming struct {
+	upstream atomic.Int64
+}
+
+// RecordUpstreamTime adds d to the time the request of ctx spent waiting on
+// services, reported apart from the gateway's own time in Server-Timing
+func RecordUpstreamTime(ctx context.Context, d time.Duration) {
+	if timing, ok := ctx.Value(requestTimingKey).(*requestTiming); ok {
+		timing.upstream.Add(int64(d))
+	}
+}
+
+// RequestID identifies every request with an X-Request-ID and a W3C
+// traceparent, and stamps both on its response whichever path answers it:
+// routes, the proxy, rejections, 404s and recovered panics. The ID of the
+// client is kept when it is well formed; the trace of the client is
+// continued with a span of the gateway. Both are forwarded to the services,
+// and an X-Request-ID a service answers with is moved to
+// X-Upstream-Request-ID. Server-Timing reports the time spent in the
+// gateway and waiting on services. It must wrap the whole router.
+func RequestID() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(RequestIDHeader)
+			if !requestIDPattern.MatchString(requestID) {
+				requestID = newRequestID()
+			}
+			traceparent := childTraceparent(r.Header.Get(TraceparentHeader))
+			r.Header.Set(RequestIDHeader, requestID)
+			r.Header.Set(TraceparentHeader, traceparent)
+
+			timing := &requestTiming{}
+			ctx := context.WithValue(r.Context(), RequestIDKey, requestID)
+			ctx = context.WithValue(ctx, requestTimingKey, timing)
+
+			tw := &tracedWriter{
+				ResponseWriter: w,
+				requestID:      requestID,
+				traceparent:    traceparent,
+				start:          time.Now(),
+				timing:         timing,
+			}
+			next(tw, r.WithContext(ctx))
+			if !tw.wroteHeader && !tw.hijacked {
+				tw.WriteHeader(http.StatusOK)
+			}
+		}
+	}
+}
+
+// tracedWriter stamps the headers of RequestID on the response as its
+// header is written
+type tracedWriter struct {
+	http.ResponseWriter
+	requestID   string
+	traceparent string
+	start       time.Time
+	timing      *requestTiming
+	wroteHeader bool
+	hijacked    bool
+}
+
+func (w *tracedWriter) WriteHeader(status int) {
+	if w.wroteHeader || status < http.StatusOK {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.wroteHeader = true
+
+	header := w.Header()
+	for _, id := range header.Values(RequestIDHeader) {
+		if id != w.requestID && header.Get(UpstreamRequestIDHeader) == "" {
+			header.Set(UpstreamRequestIDHeader, id)
+		}
+	}
+	header.Set(RequestIDHeader, w.requestID)
+	header.Set(TraceparentHeader, w.traceparent)
+	header.Set(ServerTimingHeader, serverTiming(time.Since(w.start), time.Duration(w.timing.upstream.Load())))
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *tracedWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush sends the response written so far, for streamed responses
+func (w *tracedWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack hands the connection over, for upgrades to WebSocket proxied to
+// the services
+func (w *tracedWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	w.hijacked = true
+	return hijacker.Hijack()
+}
+
+// CloseNotify reports the client going away. gin's writer requires it of
+// the writer it wraps, and the proxy asks for it.
+func (w *tracedWriter) CloseNotify() <-chan bool {
+	if notifier, ok := w.ResponseWriter.(http.CloseNotifier); ok {
+		return notifier.CloseNotify()
+	}
+	return make(chan bool)
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController
+func (w *tracedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// serverTiming formats the Server-Timing entries of a request that took
+// total, upstream of which waiting on services
+func serverTiming(total, upstream time.Duration) string {
+	gateway := total - upstream
+	if gateway < 0 {
+		gateway = 0
+	}
+	value := "gateway;dur=" + milliseconds(gateway)
+	if upstream > 0 {
+		value += ", upstream;dur=" + milliseconds(upstream)
+	}
+	return value
+}
+
+func milliseconds(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 1, 64)
+}
+
+// newRequestID returns a random version 4 UUID
+func newRequestID() string {
+	var id [16]byte
+	rand.Read(id[:])
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:])
+}
+
+// childTraceparent returns the traceparent of the gateway's span of the
+// trace parent continues, or of a new trace when parent is missing or
+// malformed
+func childTraceparent(parent string) string {
+	traceID, flags := "", "00"
+	parts := strings.Split(parent, "-")
+	if len(parts) >= 4 && isHex(parts[0], 2) && parts[0] != "ff" && (parts[0] != "00" || len(parts) == 4) &&
+		isHex(parts[1], 32) && strings.Trim(parts[1], "0") != "" &&
+		isHex(parts[2], 16) && strings.Trim(parts[2], "0") != "" &&
+		isHex(parts[3], 2) {
+		traceID, flags = parts[1], parts[3]
+	} else {
+		traceID = randomHex(16)
+	}
+	return "00-" + traceID + "-" + randomHex(8) + "-" + flags
+}
+
+// isHex reports whether s is n lowercase hex digits
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// randomHex returns n random bytes in hex, never all zeros as trace and span
+// IDs must not be
+func randomHex(n int) string {
+	b := make([]byte, n)
+	for {
+		rand.Read(b)
+		for _, c := range b {
+			if c != 0 {
+				return hex.EncodeToString(b)
+			}
+		}
+	}
+}
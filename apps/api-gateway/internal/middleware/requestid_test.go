@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/config"
+)
+
+var (
+	uuidPattern         = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	traceparentPattern  = regexp.MustCompile(`^00-[0-9a-f]{32}-[0-9a-f]{16}-[0-9a-f]{2}$`)
+	serverTimingPattern = regexp.MustCompile(`^gateway;dur=\d+\.\d(, upstream;dur=\d+\.\d)?$`)
+)
+
+// ginStep adapts a middleware to gin as the gateway does
+func ginStep(m Middleware) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		completed := false
+		m(func(w http.ResponseWriter, r *http.Request) {
+			c.Request = r
+			c.Next()
+			completed = true
+		})(c.Writer, c.Request)
+		if !completed {
+			c.Abort()
+		}
+	}
+}
+
+// newTracedGateway serves the response paths of the gateway behind
+// RequestID: routes, the proxy to upstream, authentication and rate limit
+// rejections, 404s and recovered panics
+func newTracedGateway(upstream string) http.Handler {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(gin.Recovery())
+
+	limits := RateLimit(config.RateLimitConfig{Enabled: true, RPS: 1, Window: time.Minute, RedisURL: "redis://127.0.0.1:1/0"})
+	target, _ := url.Parse(upstream)
+	proxy := httputil.NewSingleHostReverseProxy(target)
+
+	router.GET("/ok", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"ok": true}) })
+	router.GET("/private", ginStep(Authentication(config.JWTConfig{Secret: "secret"})), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.GET("/limited", ginStep(limits), func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/panic", func(c *gin.Context) { panic("boom") })
+	router.GET("/proxied", func(c *gin.Context) { proxy.ServeHTTP(c.Writer, c.Request) })
+
+	return http.HandlerFunc(RequestID()(router.ServeHTTP))
+}
+
+func TestRequestIDOnEveryResponse(t *testing.T) {
+	var forwardedID, forwardedTrace string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwardedID = r.Header.Get(RequestIDHeader)
+		forwardedTrace = r.Header.Get(TraceparentHeader)
+		time.Sleep(5 * time.Millisecond)
+		w.Header().Set(RequestIDHeader, "form-service-7")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+	gateway := newTracedGateway(upstream.URL)
+
+	// The second request to /limited is rejected
+	gateway.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/limited", nil))
+
+	for _, tc := range []struct {
+		path   string
+		status int
+	}{
+		{"/ok", http.StatusOK},
+		{"/private", http.StatusUnauthorized},
+		{"/missing", http.StatusNotFound},
+		{"/limited", http.StatusTooManyRequests},
+		{"/panic", http.StatusInternalServerError},
+		{"/proxied", http.StatusOK},
+	} {
+		w := httptest.NewRecorder()
+		gateway.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if w.Code != tc.status {
+			t.Errorf("GET %s = %d, want %d", tc.path, w.Code, tc.status)
+		}
+		if ids := w.Header().Values(RequestIDHeader); len(ids) != 1 || !uuidPattern.MatchString(ids[0]) {
+			t.Errorf("GET %s: X-Request-ID = %q, want one UUID", tc.path, ids)
+		}
+		if trace := w.Header().Get(TraceparentHeader); !traceparentPattern.MatchString(trace) {
+			t.Errorf("GET %s: traceparent = %q, want a version 00 traceparent", tc.path, trace)
+		}
+		if timing := w.Header().Get(ServerTimingHeader); !serverTimingPattern.MatchString(timing) {
+			t.Errorf("GET %s: Server-Timing = %q, want the gateway time", tc.path, timing)
+		}
+
+		if tc.path == "/proxied" {
+			if got := w.Header().Get(UpstreamRequestIDHeader); got != "form-service-7" {
+				t.Errorf("X-Upstream-Request-ID = %q, want the ID the service answered with", got)
+			}
+			if forwardedID != w.Header().Get(RequestIDHeader) || forwardedTrace != w.Header().Get(TraceparentHeader) {
+				t.Errorf("service got %q %q, want the request ID and traceparent of the response", forwardedID, forwardedTrace)
+			}
+		} else if got := w.Header().Get(UpstreamRequestIDHeader); got != "" {
+			t.Errorf("GET %s: X-Upstream-Request-ID = %q, want none", tc.path, got)
+		}
+	}
+}
+
+func TestRequestIDContinuesClient(t *testing.T) {
+	gateway := newTracedGateway("http://127.0.0.1:1")
+	parent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	r := httptest.NewRequest(http.MethodGet, "/ok", nil)
+	r.Header.Set(RequestIDHeader, "client-req.42")
+	r.Header.Set(TraceparentHeader, parent)
+	w := httptest.NewRecorder()
+	gateway.ServeHTTP(w, r)
+	if got := w.Header().Get(RequestIDHeader); got != "client-req.42" {
+		t.Errorf("X-Request-ID = %q, want the ID of the client", got)
+	}
+	trace := w.Header().Get(TraceparentHeader)
+	if !strings.HasPrefix(trace, "00-4bf92f3577b34da6a3ce929d0e0e4736-") || strings.Contains(trace, "00f067aa0ba902b7") || !strings.HasSuffix(trace, "-01") {
+		t.Errorf("traceparent = %q, want a span of the gateway in the trace of the client", trace)
+	}
+
+	// Malformed values are replaced rather than echoed
+	r = httptest.NewRequest(http.MethodGet, "/ok", nil)
+	r.Header.Set(RequestIDHeader, "bad id\r\nSet-Cookie: x")
+	r.Header.Set(TraceparentHeader, "00-00000000000000000000000000000000-00f067aa0ba902b7-01")
+	w = httptest.NewRecorder()
+	gateway.ServeHTTP(w, r)
+	if got := w.Header().Get(RequestIDHeader); !uuidPattern.MatchString(got) {
+		t.Errorf("X-Request-ID = %q, want a new UUID", got)
+	}
+	if trace := w.Header().Get(TraceparentHeader); !traceparentPattern.MatchString(trace) || strings.Contains(trace, "-00000000000000000000000000000000-") {
+		t.Errorf("traceparent = %q, want a new trace", trace)
+	}
+}
+
+func TestServerTimingSeparatesUpstream(t *testing.T) {
+	if got := serverTiming(30*time.Millisecond, 20*time.Millisecond); got != "gateway;dur=10.0, upstream;dur=20.0" {
+		t.Errorf("serverTiming = %q, want the gateway and upstream times apart", got)
+	}
+	if got := serverTiming(1500*time.Microsecond, 0); got != "gateway;dur=1.5" {
+		t.Errorf("serverTiming without upstream = %q, want the gateway time alone", got)
+	}
+}
@@ -0,0 +1,259 @@
+// Package mtls builds the transports the gateway reaches the internal services
+// with: the gateway presents a client certificate, verifies the certificate of
+// each service against the internal CA and, when configured, checks its
+// SPIFFE identity. The certificate files are reloaded when they are rotated.
+package mtls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/config"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
+)
+
+// ErrPlainHTTP is returned for a request to a service over plain HTTP while
+// mTLS is enforced
+var ErrPlainHTTP = errors.New("mtls: plain HTTP to an internal service is refused while mTLS is enforced")
+
+// Source holds the client certificate and CA bundle, reloading them when
+// the files change on disk, as when cert-manager rotates them
+type Source struct {
+	certFile string
+	keyFile  string
+	caFile   string
+	interval time.Duration
+	logger   logger.Logger
+
+	mu     sync.RWMutex
+	cert   *tls.Certificate
+	roots  *x509.CertPool
+	stamp  string
+	pooled []*http.Transport
+}
+
+// NewSource loads the certificate files of cfg
+func NewSource(cfg config.MTLSConfig, logger logger.Logger) (*Source, error) {
+	interval := cfg.ReloadInterval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	s := &Source{
+		certFile: cfg.CertFile,
+		keyFile:  cfg.KeyFile,
+		caFile:   cfg.CAFile,
+		interval: interval,
+		logger:   logger,
+	}
+	if _, err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Start checks the files for changes until ctx is cancelled
+func (s *Source) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				reloaded, err := s.Reload()
+				if err != nil {
+					s.logger.Warnf("Failed to reload the mTLS certificates, keeping the current ones: %v", err)
+				} else if reloaded {
+					s.logger.Infof("Reloaded the mTLS certificates")
+				}
+			}
+		}
+	}()
+}
+
+// Reload loads the files again when any of them changed since the last load,
+// reporting whether it did. On failure the current certificates are kept, so
+// a rotation caught halfway is retried at the next check.
+func (s *Source) Reload() (bool, error) {
+	stamp, err := fileStamp(s.certFile, s.keyFile, s.caFile)
+	if err != nil {
+		return false, err
+	}
+	s.mu.RLock()
+	unchanged := stamp == s.stamp
+	s.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(s.certFile, s.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to load the client certificate: %w", err)
+	}
+	caPEM, err := os.ReadFile(s.caFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to read the CA bundle: %w", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caPEM) {
+		return false, fmt.Errorf("CA bundle %s holds no PEM certificate", s.caFile)
+	}
+
+	s.mu.Lock()
+	s.cert, s.roots, s.stamp = &cert, roots, stamp
+	pooled := s.pooled
+	s.mu.Unlock()
+
+	// Idle connections were made with the previous certificate
+	for _, transport := range pooled {
+		transport.CloseIdleConnections()
+	}
+	return true, nil
+}
+
+// fileStamp identifies the content of files by their modification times and sizes
+func fileStamp(files ...string) (string, error) {
+	var stamp strings.Builder
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&stamp, "%s:%d:%d;", file, info.ModTime().UnixNano(), info.Size())
+	}
+	return stamp.String(), nil
+}
+
+// TLSConfig returns the client TLS configuration for a service. The server
+// certificate must chain to the CA bundle and, when identity is set, carry it
+// as a URI SAN; otherwise it must be valid for the host name dialled.
+func (s *Source) TLSConfig(identity string) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			s.mu.RLock()
+			defer s.mu.RUnlock()
+			return s.cert, nil
+		},
+		// The chain is verified in VerifyConnection against the current CA
+		// bundle, which a reload can replace
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			return s.verify(cs, identity)
+		},
+	}
+}
+
+func (s *Source) verify(cs tls.ConnectionState, identity string) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("mtls: the service presented no certificate")
+	}
+	s.mu.RLock()
+	roots := s.roots
+	s.mu.RUnlock()
+
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	if identity == "" {
+		opts.DNSName = cs.ServerName
+	}
+	leaf := cs.PeerCertificates[0]
+	if _, err := leaf.Verify(opts); err != nil {
+		return fmt.Errorf("mtls: %w", err)
+	}
+
+	if identity != "" {
+		for _, uri := range leaf.URIs {
+			if uri.String() == identity {
+				return nil
+			}
+		}
+		return fmt.Errorf("mtls: the service certificate does not carry the identity %s", identity)
+	}
+	return nil
+}
+
+// NewTransport returns a pooled transport tuned by the proxy configuration
+func NewTransport(proxy config.ProxyConfig) *http.Transport {
+	timeout := proxy.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	idleTimeout := proxy.IdleConnTimeout
+	if idleTimeout <= 0 {
+		idleTimeout = 90 * time.Second
+	}
+	maxIdle := proxy.MaxIdleConns
+	if maxIdle <= 0 {
+		maxIdle = 100
+	}
+
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   timeout,
+			KeepAlive: proxy.KeepAlive,
+		}).DialContext,
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        maxIdle,
+		MaxIdleConnsPerHost: maxIdle,
+		MaxConnsPerHost:     proxy.MaxConnsPerHost,
+		IdleConnTimeout:     idleTimeout,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+}
+
+// Transport returns the transport to one service, presenting the client
+// certificate and verifying the service against identity. With enforce,
+// requests over plain HTTP fail with ErrPlainHTTP.
+func (s *Source) Transport(proxy config.ProxyConfig, identity string, enforce bool) http.RoundTripper {
+	transport := NewTransport(proxy)
+	transport.TLSClientConfig = s.TLSConfig(identity)
+
+	s.mu.Lock()
+	s.pooled = append(s.pooled, transport)
+	s.mu.Unlock()
+
+	if !enforce {
+		return transport
+	}
+	return requireTLS{next: transport}
+}
+
+// requireTLS refuses requests that would leave the gateway unencrypted
+type requireTLS struct {
+	next *http.Transport
+}
+
+func (t requireTLS) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != "https" {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, fmt.Errorf("%w: %s", ErrPlainHTTP, req.URL.Host)
+	}
+	return t.next.RoundTrip(req)
+}
+
+// CloseIdleConnections closes the idle connections of the wrapped transport
+func (t requireTLS) CloseIdleConnections() {
+	t.next.CloseIdleConnections()
+}
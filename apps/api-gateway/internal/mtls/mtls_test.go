@@ -0,0 +1,248 @@
+package mtls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/config"
+)
+
+// testCA is a certificate authority spun up for a test
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM certificate and key signed by the CA. A spiffe:// name
+// becomes a URI SAN; the certificate is also valid for 127.0.0.1.
+func (ca *testCA) issue(t *testing.T, name string, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if strings.HasPrefix(name, "spiffe://") {
+		uri, _ := url.Parse(name)
+		template.URIs = []*url.URL{uri}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// newUpstream starts a service requiring a client certificate from clientCA,
+// answering with the common name of the client
+func newUpstream(t *testing.T, serverCA, clientCA *testCA, identity string) *httptest.Server {
+	t.Helper()
+	certPEM, keyPEM := serverCA.issue(t, identity, x509.ExtKeyUsageServerAuth)
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCA.cert)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+// writeFiles writes the client certificate, its key and the CA bundle to dir
+func writeFiles(t *testing.T, dir string, ca *testCA, clientName string, modTime time.Time) config.MTLSConfig {
+	t.Helper()
+	certPEM, keyPEM := ca.issue(t, clientName, x509.ExtKeyUsageClientAuth)
+	cfg := config.MTLSConfig{
+		Enabled:  true,
+		CertFile: filepath.Join(dir, "tls.crt"),
+		KeyFile:  filepath.Join(dir, "tls.key"),
+		CAFile:   filepath.Join(dir, "ca.crt"),
+	}
+	for file, data := range map[string][]byte{cfg.CertFile: certPEM, cfg.KeyFile: keyPEM, cfg.CAFile: ca.pem} {
+		if err := os.WriteFile(file, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(file, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return cfg
+}
+
+func get(t *testing.T, transport http.RoundTripper, url string) (string, error) {
+	t.Helper()
+	resp, err := (&http.Client{Transport: transport, Timeout: 5 * time.Second}).Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return string(body), err
+}
+
+const formServiceID = "spiffe://xform.internal/ns/default/sa/form-service"
+
+func TestTransportVerifiesTheService(t *testing.T) {
+	internalCA, otherCA := newTestCA(t, "internal"), newTestCA(t, "other")
+	source, err := NewSource(writeFiles(t, t.TempDir(), internalCA, "gateway", time.Now()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy := config.ProxyConfig{Timeout: 5 * time.Second}
+
+	upstream := newUpstream(t, internalCA, internalCA, formServiceID)
+	body, err := get(t, source.Transport(proxy, formServiceID, true), upstream.URL)
+	if err != nil {
+		t.Fatalf("mTLS request failed: %v", err)
+	}
+	if body != "gateway" {
+		t.Errorf("the service saw client %q, want gateway", body)
+	}
+
+	// Without an identity the certificate is checked against the host name
+	if _, err := get(t, source.Transport(proxy, "", true), upstream.URL); err != nil {
+		t.Errorf("request verified by host name failed: %v", err)
+	}
+
+	if _, err := get(t, source.Transport(proxy, "spiffe://xform.internal/ns/default/sa/auth-service", true), upstream.URL); err == nil ||
+		!strings.Contains(err.Error(), "does not carry the identity") {
+		t.Errorf("a service with another identity was accepted: %v", err)
+	}
+
+	impostor := newUpstream(t, otherCA, internalCA, formServiceID)
+	if _, err := get(t, source.Transport(proxy, formServiceID, true), impostor.URL); err == nil ||
+		!strings.Contains(err.Error(), "unknown authority") {
+		t.Errorf("a service certified by another CA was accepted: %v", err)
+	}
+}
+
+func TestEnforcedTransportRefusesPlainHTTP(t *testing.T) {
+	ca := newTestCA(t, "internal")
+	source, err := NewSource(writeFiles(t, t.TempDir(), ca, "gateway", time.Now()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	called := false
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+	defer plain.Close()
+
+	if _, err := get(t, source.Transport(config.ProxyConfig{}, formServiceID, true), plain.URL); !errors.Is(err, ErrPlainHTTP) {
+		t.Errorf("err = %v, want ErrPlainHTTP", err)
+	}
+	if called {
+		t.Error("the plain HTTP service was called while mTLS is enforced")
+	}
+
+	// Without enforcement plain HTTP still works, during a migration
+	if _, err := get(t, source.Transport(config.ProxyConfig{}, formServiceID, false), plain.URL); err != nil {
+		t.Errorf("plain HTTP without enforcement failed: %v", err)
+	}
+}
+
+func TestSourceReloadsRotatedCertificates(t *testing.T) {
+	ca := newTestCA(t, "internal")
+	dir := t.TempDir()
+	start := time.Now().Add(-time.Minute)
+	cfg := writeFiles(t, dir, ca, "gateway-1", start)
+	source, err := NewSource(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	upstream := newUpstream(t, ca, ca, formServiceID)
+	transport := source.Transport(config.ProxyConfig{}, formServiceID, true)
+
+	if body, err := get(t, transport, upstream.URL); err != nil || body != "gateway-1" {
+		t.Fatalf("first request = %q, %v", body, err)
+	}
+	if reloaded, err := source.Reload(); reloaded || err != nil {
+		t.Errorf("Reload of unchanged files = %v, %v; want nothing to do", reloaded, err)
+	}
+
+	// cert-manager writes the renewed certificate
+	writeFiles(t, dir, ca, "gateway-2", start.Add(time.Second))
+	if reloaded, err := source.Reload(); !reloaded || err != nil {
+		t.Fatalf("Reload of rotated files = %v, %v", reloaded, err)
+	}
+	if body, err := get(t, transport, upstream.URL); err != nil || body != "gateway-2" {
+		t.Errorf("request after rotation = %q, %v; want the new certificate", body, err)
+	}
+
+	// A rotation caught halfway keeps the current certificate
+	if err := os.WriteFile(cfg.KeyFile, []byte("partial"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := source.Reload(); err == nil {
+		t.Error("Reload accepted a broken key")
+	}
+	if body, err := get(t, transport, upstream.URL); err != nil || body != "gateway-2" {
+		t.Errorf("request after a failed reload = %q, %v", body, err)
+	}
+}
@@ -0,0 +1,375 @@
+// Package policy resolves the policy applied to a gateway request: its
+// timeout, retries, circuit breaker thresholds, rate limit tier, body size
+// limit, cache TTL and response rewriting. Policies are declared per path pattern in the
+// configuration; the most specific pattern matching a request applies.
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/config"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/routes"
+)
+
+// maxRetryAttempts bounds the retries of a route, as each multiplies the load
+// on a failing service
+const maxRetryAttempts = 5
+
+// defaultRewriteMaxBodyBytes caps the JSON bodies rewritten when the rules
+// set no cap
+const defaultRewriteMaxBodyBytes = 1 << 20
+
+// Breaker holds the circuit breaker thresholds of a route
+type Breaker struct {
+	// FailureThreshold is the number of failures opening the breaker
+	FailureThreshold int
+	// RecoveryTimeout is how long the breaker stays open
+	RecoveryTimeout time.Duration
+}
+
+// Policy is the effective policy of a route
+type Policy struct {
+	// Pattern is the path pattern declaring the policy, empty for the default
+	Pattern       string
+	Timeout       time.Duration
+	RetryAttempts int
+	Breaker       Breaker
+	// RateLimitTier is empty when the global rate limit applies
+	RateLimitTier string
+	// MaxBodyBytes is 0 when the request body is not limited
+	MaxBodyBytes int64
+	// CacheTTL is 0 when responses are not made cacheable
+	CacheTTL time.Duration
+	// Rewrite is nil when responses are proxied untouched
+	Rewrite *Rewrite
+}
+
+// Rewrite holds the rules rewriting the responses of a route
+type Rewrite struct {
+	// PublicURL replaces InternalURLs, and the URL of the service, in the
+	// headers linking to other resources
+	PublicURL    string   `json:"public_url"`
+	InternalURLs []string `json:"internal_urls,omitempty"`
+	// DropHeaders are removed; a trailing * matches a prefix
+	DropHeaders []string `json:"drop_headers,omitempty"`
+	// Body replaces URL prefixes in the strings of JSON bodies of at most
+	// MaxBodyBytes
+	Body         []URLRewrite `json:"body,omitempty"`
+	MaxBodyBytes int64        `json:"max_body_bytes"`
+}
+
+// URLRewrite replaces the URL prefix From with To
+type URLRewrite struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// MarshalJSON reports durations as strings such as "30s"
+func (p Policy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Pattern       string `json:"pattern,omitempty"`
+		Timeout       string `json:"timeout"`
+		RetryAttempts int    `json:"retry_attempts"`
+		Breaker       struct {
+			FailureThreshold int    `json:"failure_threshold"`
+			RecoveryTimeout  string `json:"recovery_timeout"`
+		} `json:"breaker"`
+		RateLimitTier string   `json:"rate_limit_tier,omitempty"`
+		MaxBodyBytes  int64    `json:"max_body_bytes"`
+		CacheTTL      string   `json:"cache_ttl"`
+		Rewrite       *Rewrite `json:"rewrite,omitempty"`
+	}{
+		Pattern:       p.Pattern,
+		Timeout:       p.Timeout.String(),
+		RetryAttempts: p.RetryAttempts,
+		Breaker: struct {
+			FailureThreshold int    `json:"failure_threshold"`
+			RecoveryTimeout  string `json:"recovery_timeout"`
+		}{p.Breaker.FailureThreshold, p.Breaker.RecoveryTimeout.String()},
+		RateLimitTier: p.RateLimitTier,
+		MaxBodyBytes:  p.MaxBodyBytes,
+		CacheTTL:      p.CacheTTL.String(),
+		Rewrite:       p.Rewrite,
+	})
+}
+
+// Resolver finds the policy of a request path
+type Resolver struct {
+	def Policy
+	// routes are ordered from the most specific pattern to the least
+	routes []Policy
+}
+
+// NewResolver validates the policies of cfg, returning an error listing every
+// problem found. Rate limit tiers must be declared in rateLimit.
+func NewResolver(cfg config.PoliciesConfig, rateLimit config.RateLimitConfig) (*Resolver, error) {
+	var errs []error
+	addf := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	def := merge(Policy{}, cfg.Default)
+	if def.Timeout <= 0 {
+		addf("default policy: timeout must be positive")
+	}
+	if def.Breaker.FailureThreshold <= 0 || def.Breaker.RecoveryTimeout <= 0 {
+		addf("default policy: breaker failure_threshold and recovery_timeout must be positive")
+	}
+	validate("default policy", def, cfg.Default, rateLimit, addf)
+
+	patterns := make([]string, 0, len(cfg.Routes))
+	for pattern := range cfg.Routes {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	r := &Resolver{def: def}
+	shapes := make(map[string]string)
+	for _, pattern := range patterns {
+		route := cfg.Routes[pattern]
+		name := "policy " + pattern
+		if err := validatePattern(pattern); err != nil {
+			addf("%s: %v", name, err)
+			continue
+		}
+		if other, ok := shapes[shape(pattern)]; ok {
+			addf("%s: matches the same paths as %s", name, other)
+			continue
+		}
+		shapes[shape(pattern)] = pattern
+
+		policy := merge(def, route)
+		policy.Pattern = pattern
+		validate(name, policy, route, rateLimit, addf)
+		r.routes = append(r.routes, policy)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(r.routes, func(i, j int) bool {
+		return moreSpecific(r.routes[i].Pattern, r.routes[j].Pattern)
+	})
+	return r, nil
+}
+
+// merge returns base with the settings of cfg applied over it
+func merge(base Policy, cfg config.RoutePolicyConfig) Policy {
+	p := base
+	if cfg.Timeout != 0 {
+		p.Timeout = cfg.Timeout
+	}
+	if cfg.RetryAttempts != nil {
+		p.RetryAttempts = *cfg.RetryAttempts
+	}
+	if cfg.Breaker.FailureThreshold != 0 {
+		p.Breaker.FailureThreshold = cfg.Breaker.FailureThreshold
+	}
+	if cfg.Breaker.RecoveryTimeout != 0 {
+		p.Breaker.RecoveryTimeout = cfg.Breaker.RecoveryTimeout
+	}
+	if cfg.RateLimitTier != "" {
+		p.RateLimitTier = cfg.RateLimitTier
+	}
+	if cfg.MaxBodyBytes != nil {
+		p.MaxBodyBytes = *cfg.MaxBodyBytes
+	}
+	if cfg.CacheTTL != nil {
+		p.CacheTTL = *cfg.CacheTTL
+	}
+	if cfg.Rewrite != nil {
+		p.Rewrite = rewrite(*cfg.Rewrite)
+	}
+	return p
+}
+
+// rewrite returns the rewrite rules of cfg, nil when disabled
+func rewrite(cfg config.RewritePolicyConfig) *Rewrite {
+	if !cfg.Enabled {
+		return nil
+	}
+	rw := &Rewrite{
+		PublicURL:    strings.TrimSuffix(cfg.PublicURL, "/"),
+		DropHeaders:  cfg.DropHeaders,
+		MaxBodyBytes: cfg.MaxBodyBytes,
+	}
+	for _, internal := range cfg.InternalURLs {
+		rw.InternalURLs = append(rw.InternalURLs, strings.TrimSuffix(internal, "/"))
+	}
+	for _, body := range cfg.Body {
+		rw.Body = append(rw.Body, URLRewrite{From: body.From, To: body.To})
+	}
+	if rw.MaxBodyBytes == 0 {
+		rw.MaxBodyBytes = defaultRewriteMaxBodyBytes
+	}
+	return rw
+}
+
+// validate checks the settings of a policy as declared in cfg
+func validate(name string, p Policy, cfg config.RoutePolicyConfig, rateLimit config.RateLimitConfig, addf func(string, ...interface{})) {
+	if cfg.Timeout < 0 {
+		addf("%s: timeout must be positive", name)
+	}
+	if p.RetryAttempts < 0 || p.RetryAttempts > maxRetryAttempts {
+		addf("%s: retry_attempts must be from 0 to %d", name, maxRetryAttempts)
+	}
+	if cfg.Breaker.FailureThreshold < 0 || cfg.Breaker.RecoveryTimeout < 0 {
+		addf("%s: breaker thresholds must be positive", name)
+	}
+	if p.RateLimitTier != "" {
+		if _, ok := rateLimit.Tiers[p.RateLimitTier]; !ok {
+			addf("%s: rate limit tier %q is not declared in security.rate_limit.tiers", name, p.RateLimitTier)
+		}
+	}
+	if p.MaxBodyBytes < 0 {
+		addf("%s: max_body_bytes must not be negative", name)
+	}
+	if p.CacheTTL < 0 {
+		addf("%s: cache_ttl must not be negative", name)
+	}
+	if rw := p.Rewrite; rw != nil {
+		if !isAbsoluteURL(rw.PublicURL) {
+			addf("%s: rewrite public_url %q is not an absolute URL", name, rw.PublicURL)
+		}
+		for _, internal := range rw.InternalURLs {
+			if !isAbsoluteURL(internal) {
+				addf("%s: rewrite internal_urls %q is not an absolute URL", name, internal)
+			}
+		}
+		for _, body := range rw.Body {
+			if body.From == "" {
+				addf("%s: rewrite body rules need a from prefix", name)
+			}
+		}
+		if rw.MaxBodyBytes < 0 {
+			addf("%s: rewrite max_body_bytes must not be negative", name)
+		}
+	}
+}
+
+func isAbsoluteURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// validatePattern checks a path pattern has the form routes.MatchPath expects
+func validatePattern(pattern string) error {
+	if !strings.HasPrefix(pattern, "/") {
+		return errors.New("pattern must start with /")
+	}
+	segments := strings.Split(strings.Trim(pattern, "/"), "/")
+	for i, segment := range segments {
+		switch {
+		case segment == "" && len(segments) > 1:
+			return errors.New("pattern has an empty segment")
+		case strings.HasPrefix(segment, "*") && i != len(segments)-1:
+			return errors.New("a *wildcard must be the last segment")
+		}
+	}
+	return nil
+}
+
+// Segment kinds, from the least specific to the most
+const (
+	segmentWildcard = iota
+	// segmentEnd ranks a pattern that ended above one continuing with a
+	// wildcard, as /forms is more specific than /forms/*
+	segmentEnd
+	segmentParam
+	segmentLiteral
+)
+
+func segmentKind(segment string) int {
+	switch {
+	case strings.HasPrefix(segment, "*"):
+		return segmentWildcard
+	case strings.HasPrefix(segment, ":"):
+		return segmentParam
+	default:
+		return segmentLiteral
+	}
+}
+
+func segments(pattern string) []string {
+	trimmed := strings.Trim(pattern, "/")
+	if trimmed == "" {
+		return nil
+	}
+	return strings.Split(trimmed, "/")
+}
+
+// moreSpecific reports whether pattern a ranks before b. Segments are
+// compared from the left: a literal beats a :param, which beats a *wildcard.
+func moreSpecific(a, b string) bool {
+	as, bs := segments(a), segments(b)
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		ak, bk := segmentEnd, segmentEnd
+		if i < len(as) {
+			ak = segmentKind(as[i])
+		}
+		if i < len(bs) {
+			bk = segmentKind(bs[i])
+		}
+		if ak != bk {
+			return ak > bk
+		}
+	}
+	return a < b
+}
+
+// shape is the pattern with parameter names dropped, equal for patterns
+// matching the same paths
+func shape(pattern string) string {
+	parts := segments(pattern)
+	for i, part := range parts {
+		switch segmentKind(part) {
+		case segmentWildcard:
+			parts[i] = "*"
+		case segmentParam:
+			parts[i] = ":"
+		}
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+// Resolve returns the policy of the most specific pattern matching path, or
+// the default policy
+func (r *Resolver) Resolve(path string) Policy {
+	for _, policy := range r.routes {
+		if routes.MatchPath(policy.Pattern, path) {
+			return policy
+		}
+	}
+	return r.def
+}
+
+// Default returns the policy of the routes matching no pattern
+func (r *Resolver) Default() Policy {
+	return r.def
+}
+
+// Routes returns the route policies, from the most specific pattern to the
+// least
+func (r *Resolver) Routes() []Policy {
+	return append([]Policy(nil), r.routes...)
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the policy of the request
+func NewContext(ctx context.Context, p Policy) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext returns the policy attached to the request, if any
+func FromContext(ctx context.Context) (Policy, bool) {
+	p, ok := ctx.Value(contextKey{}).(Policy)
+	return p, ok
+}
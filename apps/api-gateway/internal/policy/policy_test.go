@@ -0,0 +1,215 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/config"
+)
+
+func intPtr(v int) *int                          { return &v }
+func int64Ptr(v int64) *int64                    { return &v }
+func durationPtr(v time.Duration) *time.Duration { return &v }
+
+func defaultPolicy() config.RoutePolicyConfig {
+	return config.RoutePolicyConfig{
+		Timeout:      30 * time.Second,
+		Breaker:      config.BreakerPolicyConfig{FailureThreshold: 5, RecoveryTimeout: 30 * time.Second},
+		MaxBodyBytes: int64Ptr(10 << 20),
+	}
+}
+
+func rateLimitWithTiers(tiers ...string) config.RateLimitConfig {
+	rateLimit := config.RateLimitConfig{Tiers: make(map[string]config.EndpointRateLimit)}
+	for _, tier := range tiers {
+		rateLimit.Tiers[tier] = config.EndpointRateLimit{RPS: 10, Window: time.Minute}
+	}
+	return rateLimit
+}
+
+func TestMostSpecificPatternWins(t *testing.T) {
+	resolver, err := NewResolver(config.PoliciesConfig{
+		Default: defaultPolicy(),
+		Routes: map[string]config.RoutePolicyConfig{
+			"/forms/*":                       {Timeout: 15 * time.Second},
+			"/forms/:id":                     {Timeout: 5 * time.Second},
+			"/forms/changes":                 {Timeout: 2 * time.Second},
+			"/forms/:id/responses/*":         {Timeout: 45 * time.Second},
+			"/forms/:id/responses/draft":     {Timeout: 3 * time.Second},
+			"/:collection/:id/responses/all": {Timeout: 4 * time.Second},
+			"/forms":                         {Timeout: 10 * time.Second},
+		},
+	}, config.RateLimitConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path    string
+		pattern string
+	}{
+		{"/forms", "/forms"},
+		{"/forms/changes", "/forms/changes"},
+		{"/forms/123", "/forms/:id"},
+		{"/forms/123/questions", "/forms/*"},
+		{"/forms/123/responses/draft", "/forms/:id/responses/draft"},
+		{"/forms/123/responses/all", "/forms/:id/responses/*"},
+		{"/forms/123/responses/456/files", "/forms/:id/responses/*"},
+		{"/surveys/123/responses/all", "/:collection/:id/responses/all"},
+		{"/responses", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := resolver.Resolve(tt.path).Pattern; got != tt.pattern {
+				t.Errorf("Resolve(%s) = policy %q, want %q", tt.path, got, tt.pattern)
+			}
+		})
+	}
+
+	var order []string
+	for _, policy := range resolver.Routes() {
+		order = append(order, policy.Pattern)
+	}
+	want := "/forms/changes /forms/:id/responses/draft /forms/:id/responses/* /forms/:id /forms /forms/* /:collection/:id/responses/all"
+	if got := strings.Join(order, " "); got != want {
+		t.Errorf("routes ordered\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRoutePoliciesInheritTheDefault(t *testing.T) {
+	def := defaultPolicy()
+	def.RetryAttempts = intPtr(1)
+	def.CacheTTL = durationPtr(10 * time.Second)
+	resolver, err := NewResolver(config.PoliciesConfig{
+		Default: def,
+		Routes: map[string]config.RoutePolicyConfig{
+			"/responses": {
+				RateLimitTier: "submissions",
+				RetryAttempts: intPtr(0),
+				MaxBodyBytes:  int64Ptr(1 << 20),
+				Breaker:       config.BreakerPolicyConfig{FailureThreshold: 2},
+			},
+		},
+	}, rateLimitWithTiers("submissions"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := resolver.Resolve("/responses")
+	want := Policy{
+		Pattern:       "/responses",
+		Timeout:       30 * time.Second,
+		RetryAttempts: 0,
+		Breaker:       Breaker{FailureThreshold: 2, RecoveryTimeout: 30 * time.Second},
+		RateLimitTier: "submissions",
+		MaxBodyBytes:  1 << 20,
+		CacheTTL:      10 * time.Second,
+	}
+	if got != want {
+		t.Errorf("policy = %+v\nwant %+v", got, want)
+	}
+	if def := resolver.Resolve("/auth/login"); def.Pattern != "" || def.RetryAttempts != 1 {
+		t.Errorf("unmatched route got %+v, want the default policy", def)
+	}
+
+	body, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(body), `"timeout":"30s"`) || !strings.Contains(string(body), `"recovery_timeout":"30s"`) {
+		t.Errorf("JSON %s does not report durations as strings", body)
+	}
+}
+
+func TestNewResolverReportsEveryProblem(t *testing.T) {
+	_, err := NewResolver(config.PoliciesConfig{
+		Default: config.RoutePolicyConfig{},
+		Routes: map[string]config.RoutePolicyConfig{
+			"forms":           {},
+			"/forms/*/:id":    {},
+			"/forms/:id":      {},
+			"/forms/:formId":  {},
+			"/reports":        {RateLimitTier: "bulk"},
+			"/analytics":      {RetryAttempts: intPtr(9)},
+			"/responses":      {MaxBodyBytes: int64Ptr(-1)},
+			"/collaboration":  {Timeout: -time.Second},
+			"/realtime/rooms": {CacheTTL: durationPtr(-time.Second)},
+			"/events": {Rewrite: &config.RewritePolicyConfig{Enabled: true, PublicURL: "api.example.com",
+				Body: []config.URLRewriteConfig{{To: "https://api.example.com"}}}},
+		},
+	}, rateLimitWithTiers("submissions"))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{
+		"default policy: timeout must be positive",
+		"default policy: breaker failure_threshold and recovery_timeout must be positive",
+		"policy forms: pattern must start with /",
+		"policy /forms/*/:id: a *wildcard must be the last segment",
+		"policy /forms/:id: matches the same paths as /forms/:formId",
+		`policy /reports: rate limit tier "bulk" is not declared`,
+		"policy /analytics: retry_attempts must be from 0 to 5",
+		"policy /responses: max_body_bytes must not be negative",
+		"policy /collaboration: timeout must be positive",
+		"policy /realtime/rooms: cache_ttl must not be negative",
+		`policy /events: rewrite public_url "api.example.com" is not an absolute URL`,
+		"policy /events: rewrite body rules need a from prefix",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not mention %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestRewriteRules(t *testing.T) {
+	def := defaultPolicy()
+	def.Rewrite = &config.RewritePolicyConfig{Enabled: true, PublicURL: "https://api.example.com/", DropHeaders: []string{"X-Internal-*"}}
+	resolver, err := NewResolver(config.PoliciesConfig{
+		Default: def,
+		Routes: map[string]config.RoutePolicyConfig{
+			"/forms/*":   {Timeout: 15 * time.Second},
+			"/responses": {Rewrite: &config.RewritePolicyConfig{PublicURL: "https://api.example.com"}},
+		},
+	}, config.RateLimitConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rw := resolver.Resolve("/forms/123").Rewrite
+	if rw == nil || rw.PublicURL != "https://api.example.com" || rw.MaxBodyBytes != 1<<20 {
+		t.Errorf("inherited rewrite = %+v, want the default rules capped at 1 MiB", rw)
+	}
+	if rw := resolver.Resolve("/responses").Rewrite; rw != nil {
+		t.Errorf("rewrite = %+v, want none on the route disabling it", rw)
+	}
+}
+
+func TestShippedPoliciesAreValid(t *testing.T) {
+	t.Setenv("CONFIG_PATH", "../../config")
+	t.Setenv("API_GATEWAY_SECURITY_JWT_SECRET", strings.Repeat("s", 32))
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolver, err := NewResolver(cfg.Policies, cfg.Security.RateLimit)
+	if err != nil {
+		t.Fatalf("config.yaml policies are invalid:\n%v", err)
+	}
+	// Settings left out come from the default policy, not from /forms/*
+	if p := resolver.Resolve("/forms/123/questions/q1/upload-url"); p.MaxBodyBytes != 64<<10 || p.Timeout != 30*time.Second {
+		t.Errorf("upload URL policy = %+v", p)
+	}
+}
+
+func TestContext(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Error("a policy was found in an empty context")
+	}
+	ctx := NewContext(context.Background(), Policy{Pattern: "/forms/:id"})
+	if p, ok := FromContext(ctx); !ok || p.Pattern != "/forms/:id" {
+		t.Errorf("FromContext = %+v, %v", p, ok)
+	}
+}
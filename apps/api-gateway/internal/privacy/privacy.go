@@ -0,0 +1,260 @@
+// Package privacy coordinates data subject requests: the erasure or export of
+// everything the services hold about a user. A request is published to the
+// event bus, which has every participant carry it out and posts their
+// completions back. The stored request is the audit record: it keeps what each
+// participant removed or exported as counts, never the content or the email.
+package privacy
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/config"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
+)
+
+// Kind is the kind of a request
+type Kind string
+
+// Request kinds
+const (
+	KindErasure Kind = "erasure"
+	KindExport  Kind = "export"
+)
+
+// Status is the status of a request or of one of its participants
+type Status string
+
+// Statuses
+const (
+	StatusPending   Status = "pending"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+)
+
+var (
+	// ErrNotFound is returned when a request does not exist
+	ErrNotFound = errors.New("privacy request not found")
+	// ErrInvalidCompletion is returned for completions that don't belong to
+	// their request
+	ErrInvalidCompletion = errors.New("invalid privacy completion")
+)
+
+// Subject identifies the user a request is about
+type Subject struct {
+	UserID string `json:"user_id" binding:"required,uuid"`
+	// Email finds the invitations sent to the user before they signed up. It
+	// is passed on to the participants but not stored.
+	Email string `json:"email" binding:"omitempty,email"`
+}
+
+// Participant is the progress of one participant
+type Participant struct {
+	Status      Status           `json:"status"`
+	Counts      map[string]int64 `json:"counts,omitempty"`
+	Error       string           `json:"error,omitempty"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty"`
+}
+
+// Request is a data subject request
+type Request struct {
+	ID           string                  `json:"id"`
+	Kind         Kind                    `json:"kind"`
+	UserID       string                  `json:"user_id"`
+	Status       Status                  `json:"status"`
+	Participants map[string]*Participant `json:"participants"`
+	// The archive of an export, once the form service has assembled it
+	DownloadURL       string     `json:"download_url,omitempty"`
+	DownloadExpiresAt *time.Time `json:"download_expires_at,omitempty"`
+	RequestedBy       string     `json:"requested_by,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+	CompletedAt       *time.Time `json:"completed_at,omitempty"`
+}
+
+// Completion is what a participant reports once it carried out a request
+type Completion struct {
+	RequestID   string           `json:"request_id" binding:"required"`
+	Kind        Kind             `json:"kind"`
+	Participant string           `json:"participant" binding:"required"`
+	Counts      map[string]int64 `json:"counts"`
+	DownloadURL string           `json:"download_url"`
+	ExpiresAt   *time.Time       `json:"expires_at"`
+	// Error is set when the participant failed
+	Error       string    `json:"error"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// Store persists requests
+type Store interface {
+	// Create saves req, unless reuse accepts the latest request of the same
+	// kind for the user, which is returned instead
+	Create(ctx context.Context, req *Request, reuse func(*Request) bool) (*Request, bool, error)
+	Get(ctx context.Context, id string) (*Request, error)
+	// Update applies fn to the request atomically and saves it
+	Update(ctx context.Context, id string, fn func(*Request) error) (*Request, error)
+}
+
+// Publisher publishes the requested events
+type Publisher interface {
+	Publish(ctx context.Context, req *Request, subject Subject) error
+}
+
+// Service creates requests and applies their completions
+type Service struct {
+	store        Store
+	publisher    Publisher
+	participants map[Kind][]string
+	logger       logger.Logger
+	now          func() time.Time
+}
+
+// NewService creates a new privacy service
+func NewService(store Store, publisher Publisher, cfg config.PrivacyConfig, logger logger.Logger) *Service {
+	return &Service{
+		store:     store,
+		publisher: publisher,
+		participants: map[Kind][]string{
+			KindErasure: cfg.ErasureParticipants,
+			KindExport:  cfg.ExportParticipants,
+		},
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// RequestErasure requests the erasure of the data of the user. Erasure is
+// idempotent: while an earlier erasure of the user is pending or completed,
+// that request is returned and created is false.
+func (s *Service) RequestErasure(ctx context.Context, subject Subject, requestedBy string) (*Request, bool, error) {
+	return s.request(ctx, KindErasure, subject, requestedBy, func(existing *Request) bool {
+		return existing.Status != StatusFailed
+	})
+}
+
+// RequestExport requests an archive of the data of the user. A pending export
+// of the user is returned rather than started again.
+func (s *Service) RequestExport(ctx context.Context, subject Subject, requestedBy string) (*Request, bool, error) {
+	return s.request(ctx, KindExport, subject, requestedBy, func(existing *Request) bool {
+		return existing.Status == StatusPending
+	})
+}
+
+func (s *Service) request(ctx context.Context, kind Kind, subject Subject, requestedBy string, reuse func(*Request) bool) (*Request, bool, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, false, fmt.Errorf("failed to generate privacy request id: %w", err)
+	}
+
+	now := s.now().UTC()
+	req := &Request{
+		ID:           hex.EncodeToString(id),
+		Kind:         kind,
+		UserID:       subject.UserID,
+		Status:       StatusPending,
+		Participants: make(map[string]*Participant),
+		RequestedBy:  requestedBy,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	for _, name := range s.participants[kind] {
+		req.Participants[name] = &Participant{Status: StatusPending}
+	}
+
+	req, created, err := s.store.Create(ctx, req, reuse)
+	if err != nil || !created {
+		return req, false, err
+	}
+
+	if err := s.publisher.Publish(ctx, req, subject); err != nil {
+		// A failed request is not reused, so asking again starts over
+		s.store.Update(ctx, req.ID, func(r *Request) error {
+			r.Status = StatusFailed
+			r.UpdatedAt = s.now().UTC()
+			return nil
+		})
+		return nil, false, fmt.Errorf("failed to publish privacy request: %w", err)
+	}
+
+	s.logger.Infof("Privacy %s request %s for user %s created by %s", kind, req.ID, req.UserID, requestedBy)
+	return req, true, nil
+}
+
+// Get returns a request
+func (s *Service) Get(ctx context.Context, id string) (*Request, error) {
+	return s.store.Get(ctx, id)
+}
+
+// Complete records the completion of a participant. Completions are
+// delivered at least once; a repeated completion changes nothing.
+func (s *Service) Complete(ctx context.Context, completion Completion) (*Request, error) {
+	repeated := false
+	req, err := s.store.Update(ctx, completion.RequestID, func(req *Request) error {
+		if completion.Kind != "" && completion.Kind != req.Kind {
+			return fmt.Errorf("%w: request %s is an %s request", ErrInvalidCompletion, req.ID, req.Kind)
+		}
+		participant, ok := req.Participants[completion.Participant]
+		if !ok {
+			return fmt.Errorf("%w: %s is not a participant of request %s", ErrInvalidCompletion, completion.Participant, req.ID)
+		}
+		if repeated = participant.Status != StatusPending; repeated {
+			return nil
+		}
+
+		completedAt := completion.CompletedAt.UTC()
+		if completion.CompletedAt.IsZero() {
+			completedAt = s.now().UTC()
+		}
+		participant.CompletedAt = &completedAt
+		participant.Counts = completion.Counts
+		participant.Status = StatusCompleted
+		if completion.Error != "" {
+			participant.Status = StatusFailed
+			participant.Error = completion.Error
+		}
+		if completion.DownloadURL != "" {
+			req.DownloadURL = completion.DownloadURL
+			req.DownloadExpiresAt = completion.ExpiresAt
+		}
+
+		req.UpdatedAt = s.now().UTC()
+		req.Status = aggregate(req.Participants)
+		if req.Status != StatusPending {
+			req.CompletedAt = &req.UpdatedAt
+		}
+		return nil
+	})
+	if err != nil || repeated {
+		return req, err
+	}
+
+	// The audit trail: what was removed, by whom, never the content
+	if completion.Error != "" {
+		s.logger.Errorf("Privacy %s request %s: %s failed: %s", req.Kind, req.ID, completion.Participant, completion.Error)
+	} else {
+		s.logger.Infof("Privacy %s request %s: %s completed with %v", req.Kind, req.ID, completion.Participant, completion.Counts)
+	}
+	if req.Status != StatusPending {
+		s.logger.Infof("Privacy %s request %s for user %s %s", req.Kind, req.ID, req.UserID, req.Status)
+	}
+	return req, nil
+}
+
+// aggregate derives the status of a request from its participants: pending
+// until every participant reported, then failed if any of them failed
+func aggregate(participants map[string]*Participant) Status {
+	status := StatusCompleted
+	for _, participant := range participants {
+		switch participant.Status {
+		case StatusPending:
+			return StatusPending
+		case StatusFailed:
+			status = StatusFailed
+		}
+	}
+	return status
+}
@@ -0,0 +1,232 @@
+package privacy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/config"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/logger"
+)
+
+// memoryStore keeps requests in memory, copying them in and out as the Redis
+// store does
+type memoryStore struct {
+	requests map[string][]byte
+	latest   map[string]string
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{requests: map[string][]byte{}, latest: map[string]string{}}
+}
+
+func (s *memoryStore) Create(ctx context.Context, req *Request, reuse func(*Request) bool) (*Request, bool, error) {
+	subject := string(req.Kind) + ":" + req.UserID
+	if id, ok := s.latest[subject]; ok {
+		if latest, _ := s.Get(ctx, id); reuse(latest) {
+			return latest, false, nil
+		}
+	}
+	s.requests[req.ID], _ = json.Marshal(req)
+	s.latest[subject] = req.ID
+	return req, true, nil
+}
+
+func (s *memoryStore) Get(_ context.Context, id string) (*Request, error) {
+	data, ok := s.requests[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	var req Request
+	err := json.Unmarshal(data, &req)
+	return &req, err
+}
+
+func (s *memoryStore) Update(ctx context.Context, id string, fn func(*Request) error) (*Request, error) {
+	req, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(req); err != nil {
+		return nil, err
+	}
+	s.requests[id], _ = json.Marshal(req)
+	return req, nil
+}
+
+type recordingPublisher struct {
+	published []*Request
+	subjects  []Subject
+	err       error
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, req *Request, subject Subject) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.published = append(p.published, req)
+	p.subjects = append(p.subjects, subject)
+	return nil
+}
+
+func newTestService(publisher Publisher) (*Service, *memoryStore) {
+	store := newMemoryStore()
+	cfg := config.PrivacyConfig{
+		ErasureParticipants: []string{"form-service", "response-store", "collaboration-service"},
+		ExportParticipants:  []string{"form-service", "response-store"},
+	}
+	log := logger.New(logger.LogConfig{Level: "fatal", Format: "json", Output: "stderr"})
+	return NewService(store, publisher, cfg, log), store
+}
+
+const userID = "5f0c6a3e-8a44-4c1f-9d43-2b6f3f3f8c11"
+
+func TestErasureIsIdempotent(t *testing.T) {
+	publisher := &recordingPublisher{}
+	service, store := newTestService(publisher)
+	ctx := context.Background()
+
+	req, created, err := service.RequestErasure(ctx, Subject{UserID: userID, Email: "ada@example.com"}, "admin-1")
+	if err != nil || !created {
+		t.Fatalf("RequestErasure() = %v, %v", created, err)
+	}
+	if len(req.Participants) != 3 || req.Status != StatusPending {
+		t.Fatalf("request = %+v, want three pending participants", req)
+	}
+	if len(publisher.published) != 1 || publisher.subjects[0].Email != "ada@example.com" {
+		t.Fatalf("published %+v", publisher.subjects)
+	}
+	if bytes.Contains(store.requests[req.ID], []byte("ada@example.com")) {
+		t.Error("the email of the user was stored")
+	}
+
+	again, created, err := service.RequestErasure(ctx, Subject{UserID: userID}, "admin-2")
+	if err != nil || created || again.ID != req.ID {
+		t.Fatalf("second RequestErasure() = %s, %v, %v, want request %s", again.ID, created, err, req.ID)
+	}
+	if len(publisher.published) != 1 {
+		t.Errorf("the erasure was published %d times", len(publisher.published))
+	}
+
+	// An export of the same user is a request of its own
+	if _, created, _ := service.RequestExport(ctx, Subject{UserID: userID}, "admin-1"); !created {
+		t.Error("export reused the erasure")
+	}
+}
+
+func TestCompletionsAggregateStatus(t *testing.T) {
+	service, _ := newTestService(&recordingPublisher{})
+	ctx := context.Background()
+	req, _, _ := service.RequestErasure(ctx, Subject{UserID: userID}, "admin-1")
+
+	complete := func(participant, failure string, counts map[string]int64) *Request {
+		t.Helper()
+		updated, err := service.Complete(ctx, Completion{
+			RequestID: req.ID, Kind: KindErasure, Participant: participant,
+			Counts: counts, Error: failure, CompletedAt: time.Now(),
+		})
+		if err != nil {
+			t.Fatalf("Complete(%s) error: %v", participant, err)
+		}
+		return updated
+	}
+
+	if updated := complete("form-service", "", map[string]int64{"forms_deleted": 3}); updated.Status != StatusPending {
+		t.Errorf("status = %s after one participant", updated.Status)
+	}
+	// A redelivered completion changes nothing
+	if updated := complete("form-service", "", map[string]int64{"forms_deleted": 0}); updated.Participants["form-service"].Counts["forms_deleted"] != 3 {
+		t.Errorf("repeated completion overwrote the counts: %+v", updated.Participants["form-service"])
+	}
+	complete("response-store", "", map[string]int64{"responses_anonymized": 5})
+	updated := complete("collaboration-service", "redis unavailable", nil)
+	if updated.Status != StatusFailed || updated.CompletedAt == nil {
+		t.Errorf("status = %s, want failed once every participant reported", updated.Status)
+	}
+
+	// A failed erasure is requested anew
+	if _, created, _ := service.RequestErasure(ctx, Subject{UserID: userID}, "admin-1"); !created {
+		t.Error("failed erasure was reused")
+	}
+
+	_, err := service.Complete(ctx, Completion{RequestID: req.ID, Participant: "billing"})
+	if !errors.Is(err, ErrInvalidCompletion) {
+		t.Errorf("unknown participant error = %v", err)
+	}
+	if _, err := service.Complete(ctx, Completion{RequestID: "missing", Participant: "form-service"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown request error = %v", err)
+	}
+}
+
+func TestExportCarriesTheDownloadLink(t *testing.T) {
+	service, _ := newTestService(&recordingPublisher{})
+	ctx := context.Background()
+	req, _, _ := service.RequestExport(ctx, Subject{UserID: userID}, "admin-1")
+
+	expires := time.Now().Add(24 * time.Hour).UTC()
+	service.Complete(ctx, Completion{RequestID: req.ID, Participant: "response-store", Counts: map[string]int64{"submissions": 4}})
+	updated, err := service.Complete(ctx, Completion{
+		RequestID: req.ID, Participant: "form-service",
+		DownloadURL: "https://files.example.com/export.json", ExpiresAt: &expires,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Status != StatusCompleted || updated.DownloadURL == "" || updated.DownloadExpiresAt == nil {
+		t.Errorf("export = %+v, want completed with the link", updated)
+	}
+
+	// A completed export is started again
+	if again, created, _ := service.RequestExport(ctx, Subject{UserID: userID}, "admin-1"); !created || again.ID == req.ID {
+		t.Error("completed export was reused")
+	}
+}
+
+func TestFailedPublishFailsTheRequest(t *testing.T) {
+	publisher := &recordingPublisher{err: errors.New("event bus unavailable")}
+	service, store := newTestService(publisher)
+	ctx := context.Background()
+
+	if _, _, err := service.RequestErasure(ctx, Subject{UserID: userID}, "admin-1"); err == nil {
+		t.Fatal("expected the publish error")
+	}
+	failed, _ := store.Get(ctx, store.latest["erasure:"+userID])
+	if failed.Status != StatusFailed {
+		t.Errorf("status = %s, want failed", failed.Status)
+	}
+
+	publisher.err = nil
+	if _, created, err := service.RequestErasure(ctx, Subject{UserID: userID}, "admin-1"); err != nil || !created {
+		t.Errorf("retry = %v, %v, want a new request", created, err)
+	}
+}
+
+func TestEventBusPublisher(t *testing.T) {
+	var event map[string]interface{}
+	bus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/events" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&event)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer bus.Close()
+
+	publisher := NewEventBusPublisher(bus.URL+"/", time.Second)
+	req := &Request{ID: "req-1", Kind: KindErasure, UserID: userID, Participants: map[string]*Participant{"form-service": {}}}
+	if err := publisher.Publish(context.Background(), req, Subject{UserID: userID, Email: "ada@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if event["event_type"] != "privacy.erasure.requested" || event["key"] != userID || event["id"] != "req-1" {
+		t.Errorf("event = %v", event)
+	}
+	if data := event["data"].(map[string]interface{}); data["email"] != "ada@example.com" || data["request_id"] != "req-1" {
+		t.Errorf("data = %v", data)
+	}
+}
@@ -0,0 +1,75 @@
+package privacy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// eventSource is the source of the requested events
+const eventSource = "api-gateway"
+
+// EventBusPublisher publishes requested events through the HTTP API of the
+// event bus
+type EventBusPublisher struct {
+	url    string
+	client *http.Client
+}
+
+// NewEventBusPublisher creates a publisher to the event bus at eventBusURL
+func NewEventBusPublisher(eventBusURL string, timeout time.Duration) *EventBusPublisher {
+	return &EventBusPublisher{
+		url:    strings.TrimSuffix(eventBusURL, "/") + "/events",
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Publish publishes privacy.<kind>.requested, keyed by the user so the
+// requests of a user are carried out in order. The request ID is the event ID,
+// which makes a republished request a duplicate.
+func (p *EventBusPublisher) Publish(ctx context.Context, req *Request, subject Subject) error {
+	participants := make([]string, 0, len(req.Participants))
+	for name := range req.Participants {
+		participants = append(participants, name)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"id":         req.ID,
+		"event_type": fmt.Sprintf("privacy.%s.requested", req.Kind),
+		"source":     eventSource,
+		"subject":    req.UserID,
+		"key":        req.UserID,
+		"data": map[string]interface{}{
+			"request_id":   req.ID,
+			"kind":         req.Kind,
+			"user_id":      subject.UserID,
+			"email":        subject.Email,
+			"participants": participants,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("event bus answered %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
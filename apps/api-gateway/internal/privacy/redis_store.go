@@ -0,0 +1,143 @@
+package privacy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	requestPrefix = "privacy:request:"
+	// subjectPrefix maps a kind and user to their latest request
+	subjectPrefix = "privacy:subject:"
+)
+
+// maxTxAttempts bounds the retries of a transaction whose keys changed
+const maxTxAttempts = 5
+
+// RedisStore keeps requests in Redis. Requests are kept without expiry, as
+// the audit record of the erasures.
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore creates a store backed by the Redis instance at redisURL
+func NewRedisStore(redisURL string) (*RedisStore, error) {
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Redis URL: %w", err)
+	}
+	return &RedisStore{client: redis.NewClient(opts)}, nil
+}
+
+// Create saves req unless reuse accepts the latest request of the same kind
+// for the user. Concurrent requests for the same user create one request.
+func (s *RedisStore) Create(ctx context.Context, req *Request, reuse func(*Request) bool) (*Request, bool, error) {
+	subjectKey := subjectPrefix + string(req.Kind) + ":" + req.UserID
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to encode privacy request: %w", err)
+	}
+
+	var existing *Request
+	err = s.transact(ctx, func(tx *redis.Tx) error {
+		existing = nil
+		id, err := tx.Get(ctx, subjectKey).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return err
+		}
+		if id != "" {
+			latest, err := s.get(ctx, tx, id)
+			if err != nil && !errors.Is(err, ErrNotFound) {
+				return err
+			}
+			if latest != nil && reuse(latest) {
+				existing = latest
+				return nil
+			}
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, requestPrefix+req.ID, data, 0)
+			pipe.Set(ctx, subjectKey, req.ID, 0)
+			return nil
+		})
+		return err
+	}, subjectKey)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to store privacy request: %w", err)
+	}
+	if existing != nil {
+		return existing, false, nil
+	}
+	return req, true, nil
+}
+
+// Get loads a request
+func (s *RedisStore) Get(ctx context.Context, id string) (*Request, error) {
+	return s.get(ctx, s.client, id)
+}
+
+// Update applies fn to the request, retrying when it changed concurrently
+func (s *RedisStore) Update(ctx context.Context, id string, fn func(*Request) error) (*Request, error) {
+	key := requestPrefix + id
+	var updated *Request
+	err := s.transact(ctx, func(tx *redis.Tx) error {
+		req, err := s.get(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if err := fn(req); err != nil {
+			return err
+		}
+		data, err := json.Marshal(req)
+		if err != nil {
+			return fmt.Errorf("failed to encode privacy request: %w", err)
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, data, 0)
+			return nil
+		})
+		updated = req
+		return err
+	}, key)
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// Close closes the Redis client
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
+
+// transact runs fn watching keys, again when they changed before it committed
+func (s *RedisStore) transact(ctx context.Context, fn func(tx *redis.Tx) error, keys ...string) error {
+	var err error
+	for attempt := 0; attempt < maxTxAttempts; attempt++ {
+		if err = s.client.Watch(ctx, fn, keys...); !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+	}
+	return err
+}
+
+func (s *RedisStore) get(ctx context.Context, client redis.Cmdable, id string) (*Request, error) {
+	data, err := client.Get(ctx, requestPrefix+id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load privacy request: %w", err)
+	}
+
+	var req Request
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, fmt.Errorf("invalid privacy request record %s: %w", id, err)
+	}
+	return &req, nil
+}
@@ -0,0 +1,157 @@
+package routes
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// manifestGlob finds the route manifests the services generate, e.g.
+// apps/form-service/docs/routes.json
+const manifestGlob = "../../../*/docs/routes.json"
+
+type manifestRoute struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Auth   Auth   `json:"auth"`
+}
+
+type manifest struct {
+	Service string          `json:"service"`
+	Routes  []manifestRoute `json:"routes"`
+}
+
+func loadManifests(t *testing.T) map[string]manifest {
+	t.Helper()
+	files, err := filepath.Glob(manifestGlob)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifests := make(map[string]manifest, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var m manifest
+		if err := json.Unmarshal(data, &m); err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		manifests[m.Service] = m
+	}
+	if len(manifests) == 0 {
+		t.Fatalf("no route manifests match %s", manifestGlob)
+	}
+	return manifests
+}
+
+// TestProxiesMatchServiceRoutes checks every proxied prefix against the route
+// manifest of its service: the prefix must reach at least one route, the
+// gateway must enforce the authentication each route expects, and routes the
+// gateway opens to anonymous callers must still exist.
+func TestProxiesMatchServiceRoutes(t *testing.T) {
+	manifests := loadManifests(t)
+
+	for i := range Proxies {
+		proxy := &Proxies[i]
+		t.Run(strings.TrimPrefix(proxy.Prefix, "/"), func(t *testing.T) {
+			m, ok := manifests[proxy.Service]
+			if !ok {
+				t.Skipf("%s publishes no route manifest", proxy.Service)
+			}
+
+			reached := 0
+			for _, route := range m.Routes {
+				relative, ok := upstreamRelative(proxy.Upstream, route.Path)
+				if !ok {
+					continue
+				}
+				reached++
+
+				gatewayPath := proxy.Prefix + relative
+				if auth := proxy.AuthFor(route.Method, gatewayPath); auth != route.Auth {
+					t.Errorf("%s %s: gateway enforces %s auth, but %s serves %s %s with %s auth",
+						route.Method, gatewayPath, auth, proxy.Service, route.Method, route.Path, route.Auth)
+				}
+			}
+			if reached == 0 {
+				t.Errorf("%s proxies %s to %s%s, which serves no routes there", proxy.Prefix, proxy.Service, proxy.Service, proxy.Upstream)
+			}
+
+			for _, exception := range proxy.Routes {
+				if !serves(m, proxy.Upstream+exception.Path, exception.Method) {
+					t.Errorf("%s %s%s is %s on the gateway, but %s serves no %s %s%s",
+						exception.Method, proxy.Prefix, exception.Path, exception.Auth,
+						proxy.Service, exception.Method, proxy.Upstream, exception.Path)
+				}
+			}
+		})
+	}
+}
+
+// upstreamRelative returns the part of a service route below the upstream prefix
+func upstreamRelative(upstream, path string) (string, bool) {
+	if path == upstream {
+		return "", true
+	}
+	if strings.HasPrefix(path, upstream+"/") {
+		return strings.TrimPrefix(path, upstream), true
+	}
+	return "", false
+}
+
+func serves(m manifest, path, method string) bool {
+	for _, route := range m.Routes {
+		if route.Method == method && MatchPath(route.Path, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestAuthFor(t *testing.T) {
+	tests := []struct {
+		method, path string
+		want         Auth
+	}{
+		{"GET", "/forms/f1", AuthOptional},
+		{"PUT", "/forms/f1", AuthRequired},
+		{"POST", "/forms", AuthRequired},
+		{"POST", "/forms/f1/questions/q1/upload-url", AuthOptional},
+		{"POST", "/forms/f1/questions/q1/files/verify", AuthRequired},
+		{"GET", "/forms/f1/responses/draft", AuthOptional},
+		{"POST", "/responses", AuthOptional},
+		{"GET", "/responses", AuthRequired},
+		{"GET", "/responses/r1/receipt.pdf", AuthOptional},
+		{"GET", "/responses/r1", AuthRequired},
+		{"GET", "/public/forms/by-slug/feedback", AuthPublic},
+		{"POST", "/public/forms/f1/beacon", AuthPublic},
+		{"GET", "/analytics/forms/f1/funnel", AuthRequired},
+		{"GET", "/formsx/f1", AuthRequired},
+		{"GET", "/unknown", AuthRequired},
+	}
+	for _, tt := range tests {
+		if got := AuthFor(tt.method, tt.path); got != tt.want {
+			t.Errorf("AuthFor(%s, %s) = %s, want %s", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestUpstreamPath(t *testing.T) {
+	proxy, ok := Match("/forms/f1/publish")
+	if !ok {
+		t.Fatal("no proxy for /forms")
+	}
+	for path, want := range map[string]string{
+		"/forms":            "/api/v1/forms",
+		"/forms/":           "/api/v1/forms",
+		"/forms/f1/publish": "/api/v1/forms/f1/publish",
+	} {
+		if got := proxy.UpstreamPath(path); got != want {
+			t.Errorf("UpstreamPath(%s) = %s, want %s", path, got, want)
+		}
+	}
+}
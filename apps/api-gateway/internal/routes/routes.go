@@ -0,0 +1,200 @@
+// Package routes declares the prefixes the gateway proxies to backend
+// services, where each lands on the service and the authentication the
+// gateway enforces on the routes below it. The contract tests check this
+// table against the route manifests the services publish.
+package routes
+
+import (
+	"strings"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/apikey"
+)
+
+// Auth is the authentication the gateway enforces on a route
+type Auth string
+
+const (
+	// AuthPublic routes are proxied without checking credentials
+	AuthPublic Auth = "public"
+	// AuthOptional routes are proxied anonymously unless a token is sent,
+	// which must then be valid
+	AuthOptional Auth = "optional"
+	// AuthRequired routes reject anonymous callers
+	AuthRequired Auth = "required"
+)
+
+// Proxy is a gateway prefix proxied to a service
+type Proxy struct {
+	// Prefix of the gateway path, e.g. /forms
+	Prefix string
+	// Service the requests are proxied to
+	Service string
+	// Upstream replaces Prefix in the proxied path, e.g. /api/v1/forms
+	Upstream string
+	// ReadScope and WriteScope are required of API keys calling the prefix
+	ReadScope  string
+	WriteScope string
+	// Routes lists the routes below the prefix not requiring authentication,
+	// and the required ones they would otherwise shadow. The first route
+	// matching a request applies.
+	Routes []Route
+}
+
+// Route is a route below a proxied prefix
+type Route struct {
+	Method string
+	// Path below the prefix, with :param segments, e.g. /:id/responses/draft
+	Path string
+	Auth Auth
+}
+
+// Proxies are the prefixes proxied by the gateway
+var Proxies = []Proxy{
+	{Prefix: "/auth", Service: "auth-service", Upstream: "/auth", ReadScope: apikey.ScopeAdmin, WriteScope: apikey.ScopeAdmin},
+	{
+		Prefix:     "/forms",
+		Service:    "form-service",
+		Upstream:   "/api/v1/forms",
+		ReadScope:  apikey.ScopeReadForms,
+		WriteScope: apikey.ScopeWriteForms,
+		// Respondents fill in published forms without an account
+		Routes: []Route{
+			{Method: "GET", Path: "/changes", Auth: AuthRequired},
+			{Method: "GET", Path: "/:id", Auth: AuthOptional},
+			{Method: "POST", Path: "/:id/questions/:qid/upload-url", Auth: AuthOptional},
+			{Method: "GET", Path: "/:id/responses/draft", Auth: AuthOptional},
+			{Method: "PUT", Path: "/:id/responses/draft", Auth: AuthOptional},
+		},
+	},
+	{
+		Prefix:     "/responses",
+		Service:    "response-service",
+		Upstream:   "/api/v1/responses",
+		ReadScope:  apikey.ScopeReadResponses,
+		WriteScope: apikey.ScopeWriteResponses,
+		// Anonymous submissions, also from embedded forms, and edits and
+		// receipts with the edit token
+		Routes: []Route{
+			{Method: "OPTIONS", Path: "", Auth: AuthPublic},
+			{Method: "POST", Path: "", Auth: AuthOptional},
+			{Method: "PUT", Path: "/:id", Auth: AuthOptional},
+			{Method: "GET", Path: "/:id/receipt.pdf", Auth: AuthOptional},
+		},
+	},
+	{
+		Prefix:     "/public/forms",
+		Service:    "form-service",
+		Upstream:   "/api/v1/public/forms",
+		ReadScope:  apikey.ScopeReadForms,
+		WriteScope: apikey.ScopeWriteForms,
+		// Published forms resolved from the slugs of their public URLs,
+		// embedded on other sites, their funnel beacons and public results,
+		// drafts shared with preview tokens, and exports downloaded with
+		// single-use links
+		Routes: []Route{
+			{Method: "GET", Path: "/by-slug/:slug", Auth: AuthPublic},
+			{Method: "GET", Path: "/preview", Auth: AuthPublic},
+			{Method: "GET", Path: "/:id/embed.js", Auth: AuthPublic},
+			{Method: "GET", Path: "/:id/definition", Auth: AuthPublic},
+			{Method: "POST", Path: "/:id/beacon", Auth: AuthPublic},
+			{Method: "GET", Path: "/:id/results", Auth: AuthPublic},
+			{Method: "GET", Path: "/:id/exports/:jobId/download", Auth: AuthPublic},
+		},
+	},
+	{Prefix: "/library", Service: "form-service", Upstream: "/api/v1/library", ReadScope: apikey.ScopeReadForms, WriteScope: apikey.ScopeWriteForms},
+	{Prefix: "/transfers", Service: "form-service", Upstream: "/api/v1/transfers", ReadScope: apikey.ScopeReadForms, WriteScope: apikey.ScopeWriteForms},
+	{Prefix: "/organizations", Service: "form-service", Upstream: "/api/v1/organizations", ReadScope: apikey.ScopeAdmin, WriteScope: apikey.ScopeAdmin},
+	{Prefix: "/usage", Service: "form-service", Upstream: "/api/v1/usage", ReadScope: apikey.ScopeAdmin, WriteScope: apikey.ScopeAdmin},
+	// Drill-down queries and funnels of form owners, answered by the form
+	// service from the event store projections; only reads, POST included
+	{Prefix: "/analytics/forms", Service: "form-service", Upstream: "/api/v1/analytics/forms", ReadScope: apikey.ScopeReadResponses, WriteScope: apikey.ScopeReadResponses},
+	{Prefix: "/analytics", Service: "analytics-service", Upstream: "/analytics", ReadScope: apikey.ScopeAdmin, WriteScope: apikey.ScopeAdmin},
+	{Prefix: "/reports", Service: "analytics-service", Upstream: "/reports", ReadScope: apikey.ScopeAdmin, WriteScope: apikey.ScopeAdmin},
+	{Prefix: "/collaboration", Service: "collaboration-service", Upstream: "/collaboration", ReadScope: apikey.ScopeAdmin, WriteScope: apikey.ScopeAdmin},
+	{Prefix: "/realtime", Service: "realtime-service", Upstream: "/realtime", ReadScope: apikey.ScopeAdmin, WriteScope: apikey.ScopeAdmin},
+	{Prefix: "/events", Service: "event-bus-service", Upstream: "/events", ReadScope: apikey.ScopeAdmin, WriteScope: apikey.ScopeAdmin},
+}
+
+// Match returns the proxy serving path
+func Match(path string) (*Proxy, bool) {
+	for i := range Proxies {
+		if _, ok := Proxies[i].relative(path); ok {
+			return &Proxies[i], true
+		}
+	}
+	return nil, false
+}
+
+// AuthFor returns the authentication the gateway enforces on a request.
+// Paths not proxied require authentication.
+func AuthFor(method, path string) Auth {
+	proxy, ok := Match(path)
+	if !ok {
+		return AuthRequired
+	}
+	return proxy.AuthFor(method, path)
+}
+
+// AuthFor returns the authentication enforced on a request below the prefix
+func (p *Proxy) AuthFor(method, path string) Auth {
+	relative, ok := p.relative(path)
+	if !ok {
+		return AuthRequired
+	}
+	for _, route := range p.Routes {
+		if route.Method == method && MatchPath(route.Path, relative) {
+			return route.Auth
+		}
+	}
+	return AuthRequired
+}
+
+// UpstreamPath maps a gateway path below the prefix onto the service
+func (p *Proxy) UpstreamPath(path string) string {
+	relative, ok := p.relative(path)
+	if !ok {
+		return path
+	}
+	// Services route the collection itself without a trailing slash
+	if relative == "/" {
+		relative = ""
+	}
+	return p.Upstream + relative
+}
+
+// relative returns path below the prefix, which is empty for the prefix itself
+func (p *Proxy) relative(path string) (string, bool) {
+	if path == p.Prefix {
+		return "", true
+	}
+	if strings.HasPrefix(path, p.Prefix+"/") {
+		return strings.TrimPrefix(path, p.Prefix), true
+	}
+	return "", false
+}
+
+// MatchPath matches a path against a template with :param and *wildcard
+// segments
+func MatchPath(template, path string) bool {
+	templateParts := strings.Split(strings.Trim(template, "/"), "/")
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+
+	for i, part := range templateParts {
+		if strings.HasPrefix(part, "*") {
+			return true
+		}
+		if i >= len(pathParts) {
+			return false
+		}
+		if strings.HasPrefix(part, ":") {
+			if pathParts[i] == "" {
+				return false
+			}
+			continue
+		}
+		if part != pathParts[i] {
+			return false
+		}
+	}
+	return len(templateParts) == len(pathParts)
+}
@@ -0,0 +1,261 @@
+// Package shed rejects the lowest-priority gateway traffic while the gateway
+// is overloaded, rather than letting requests queue behind a slow service
+// until goroutines and memory run out. Pressure comes from the number of
+// requests in flight and from the recent p99 latency of each route class; as
+// it rises, anonymous requests are shed first, then requests to non-critical
+// classes, then every request but the exempt ones.
+package shed
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/config"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/metrics"
+)
+
+// Reason is why a request was shed
+type Reason string
+
+const (
+	// ReasonInFlight sheds requests past an in-flight limit
+	ReasonInFlight Reason = "in_flight"
+	// ReasonLatency sheds requests to a class past its latency target
+	ReasonLatency Reason = "latency"
+)
+
+// DefaultClass is the class of requests matching no configured class
+const DefaultClass = "default"
+
+// ExemptClass is reported for the requests to exempt paths
+const ExemptClass = "exempt"
+
+// minLatencySamples is the number of requests in the window below which the
+// p99 latency of a class is not trusted
+const minLatencySamples = 20
+
+// evaluateInterval is how often the latencies of the classes are checked
+const evaluateInterval = time.Second
+
+// latency pressure of a class, as set by the last evaluation
+const (
+	pressureNone int32 = iota
+	// pressureAnonymous sheds the anonymous requests to the class
+	pressureAnonymous
+	// pressureAll sheds every request to the class
+	pressureAll
+)
+
+// class is a route class with the latency window its pressure comes from
+type class struct {
+	name     string
+	prefixes []string
+	critical bool
+	target   time.Duration
+	latency  *metrics.LatencyWindow
+	pressure atomic.Int32
+}
+
+// Shedder admits or sheds requests. The decision reads counters only; the
+// latency percentiles are evaluated in the background.
+type Shedder struct {
+	maxInFlight         int64
+	anonymousInFlight   int64
+	nonCriticalInFlight int64
+	window              time.Duration
+	retryAfter          int
+	exempt              []string
+	classes             []*class
+	def                 *class
+	metrics             *metrics.Collector
+	now                 func() time.Time
+
+	inFlight atomic.Int64
+}
+
+// New creates a shedder with the limits of cfg, recording shed requests in
+// collector. Anonymous and non-critical limits of 0 shed those requests only
+// at the max.
+func New(cfg config.LoadSheddingConfig, collector *metrics.Collector) *Shedder {
+	limit := func(n int) int64 {
+		if n <= 0 || n > cfg.MaxInFlight {
+			return int64(cfg.MaxInFlight)
+		}
+		return int64(n)
+	}
+	buckets := metrics.DefaultConfig().HistogramBuckets
+
+	s := &Shedder{
+		maxInFlight:         int64(cfg.MaxInFlight),
+		anonymousInFlight:   limit(cfg.AnonymousInFlight),
+		nonCriticalInFlight: limit(cfg.NonCriticalInFlight),
+		window:              cfg.LatencyWindow,
+		retryAfter:          int(math.Ceil(cfg.RetryAfter.Seconds())),
+		exempt:              cfg.ExemptPaths,
+		metrics:             collector,
+		now:                 time.Now,
+	}
+	for _, c := range cfg.Classes {
+		s.classes = append(s.classes, &class{
+			name:     c.Name,
+			prefixes: c.Prefixes,
+			critical: c.Critical,
+			target:   c.LatencyTarget,
+			latency:  metrics.NewLatencyWindow(buckets),
+		})
+	}
+	s.def = &class{name: DefaultClass, latency: metrics.NewLatencyWindow(buckets)}
+	return s
+}
+
+// Start evaluates the latencies of the classes every second until ctx is cancelled
+func (s *Shedder) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(evaluateInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.evaluate(s.now())
+			}
+		}
+	}()
+}
+
+// Ticket is the decision on a request. An admitted request is counted in
+// flight until Done is called.
+type Ticket struct {
+	// Class is the route class of the request
+	Class string
+	// Reason is why the request was shed, empty when it was admitted
+	Reason Reason
+
+	shedder *Shedder
+	class   *class
+	start   time.Time
+}
+
+// Admitted reports whether the request may go on
+func (t Ticket) Admitted() bool {
+	return t.Reason == ""
+}
+
+// Done ends an admitted request, recording its latency for its class
+func (t Ticket) Done() {
+	if t.shedder == nil {
+		return
+	}
+	now := t.shedder.now()
+	t.class.latency.Observe(now, now.Sub(t.start))
+	t.shedder.inFlight.Add(-1)
+}
+
+// Admit decides on a request to path from a caller that is anonymous or not
+func (s *Shedder) Admit(path string, anonymous bool) Ticket {
+	for _, prefix := range s.exempt {
+		if strings.HasPrefix(path, prefix) {
+			return Ticket{Class: ExemptClass}
+		}
+	}
+	c := s.classOf(path)
+
+	// The request is counted before the decision so concurrent requests
+	// can't all slip under a limit
+	n := s.inFlight.Add(1)
+	if reason := s.reason(n, c, anonymous); reason != "" {
+		s.inFlight.Add(-1)
+		if s.metrics != nil {
+			s.metrics.RecordShedRequest(string(reason), c.name)
+		}
+		return Ticket{Class: c.name, Reason: reason}
+	}
+	return Ticket{Class: c.name, shedder: s, class: c, start: s.now()}
+}
+
+// reason returns why a request to c is shed with n requests in flight,
+// counting it, or "" when it is admitted
+func (s *Shedder) reason(n int64, c *class, anonymous bool) Reason {
+	switch {
+	case n > s.maxInFlight,
+		!c.critical && n > s.nonCriticalInFlight,
+		anonymous && n > s.anonymousInFlight:
+		return ReasonInFlight
+	}
+	switch c.pressure.Load() {
+	case pressureAll:
+		return ReasonLatency
+	case pressureAnonymous:
+		if anonymous {
+			return ReasonLatency
+		}
+	}
+	return ""
+}
+
+// classOf returns the first class with a prefix of path
+func (s *Shedder) classOf(path string) *class {
+	for _, c := range s.classes {
+		for _, prefix := range c.prefixes {
+			if strings.HasPrefix(path, prefix) {
+				return c
+			}
+		}
+	}
+	return s.def
+}
+
+// evaluate sets the pressure of each class from its p99 latency over the
+// window. A class shed entirely on latency is admitted again once its slow
+// requests have left the window.
+func (s *Shedder) evaluate(now time.Time) {
+	for _, c := range s.classes {
+		if c.target <= 0 {
+			continue
+		}
+		pressure := pressureNone
+		if stats := c.latency.Stats(now, s.window); stats.Requests >= minLatencySamples {
+			p99 := time.Duration(stats.P99 * float64(time.Millisecond))
+			switch {
+			case p99 > 2*c.target && !c.critical:
+				pressure = pressureAll
+			case p99 > c.target:
+				pressure = pressureAnonymous
+			}
+		}
+		c.pressure.Store(pressure)
+	}
+}
+
+// InFlight returns the number of admitted requests being served
+func (s *Shedder) InFlight() int64 {
+	return s.inFlight.Load()
+}
+
+// Wrap sheds the requests to next. anonymous tells whether the caller of a
+// request is unauthenticated.
+func (s *Shedder) Wrap(next http.Handler, anonymous func(*http.Request) bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ticket := s.Admit(r.URL.Path, anonymous(r))
+		if !ticket.Admitted() {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Retry-After", strconv.Itoa(s.retryAfter))
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":   "overloaded",
+				"message": "The gateway is overloaded. Please try again later.",
+			})
+			return
+		}
+		defer ticket.Done()
+		next.ServeHTTP(w, r)
+	})
+}
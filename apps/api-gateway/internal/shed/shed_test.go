@@ -0,0 +1,274 @@
+package shed
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/internal/config"
+	"github.com/Mir00r/X-Form-Backend/enhanced-architecture/api-gateway/pkg/metrics"
+)
+
+func testConfig() config.LoadSheddingConfig {
+	return config.LoadSheddingConfig{
+		Enabled:             true,
+		MaxInFlight:         10,
+		AnonymousInFlight:   4,
+		NonCriticalInFlight: 7,
+		LatencyWindow:       30 * time.Second,
+		RetryAfter:          2 * time.Second,
+		ExemptPaths:         []string{"/health", "/api/v1/admin"},
+		Classes: []config.RouteClassConfig{
+			{Name: "submissions", Prefixes: []string{"/api/v1/responses", "/api/v1/public"}, Critical: true, LatencyTarget: 100 * time.Millisecond},
+			{Name: "reports", Prefixes: []string{"/api/v1/reports"}, LatencyTarget: 100 * time.Millisecond},
+		},
+	}
+}
+
+// fill admits n authenticated requests to a critical class, keeping them in flight
+func fill(t *testing.T, s *Shedder, n int) []Ticket {
+	t.Helper()
+	tickets := make([]Ticket, 0, n)
+	for i := 0; i < n; i++ {
+		ticket := s.Admit("/api/v1/responses", false)
+		if !ticket.Admitted() {
+			t.Fatalf("filling request %d was shed: %s", i+1, ticket.Reason)
+		}
+		tickets = append(tickets, ticket)
+	}
+	return tickets
+}
+
+func TestAdmitInFlightLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		inFlight  int
+		path      string
+		anonymous bool
+		want      Reason
+		class     string
+	}{
+		{"anonymous below its limit", 3, "/api/v1/forms", true, "", DefaultClass},
+		{"anonymous at its limit", 4, "/api/v1/forms", true, ReasonInFlight, DefaultClass},
+		{"anonymous to a critical class", 4, "/api/v1/public/forms/f-1/submit", true, ReasonInFlight, "submissions"},
+		{"non-critical below its limit", 6, "/api/v1/forms", false, "", DefaultClass},
+		{"non-critical at its limit", 7, "/api/v1/reports/r-1", false, ReasonInFlight, "reports"},
+		{"critical past the non-critical limit", 9, "/api/v1/responses", false, "", "submissions"},
+		{"critical at the max", 10, "/api/v1/responses", false, ReasonInFlight, "submissions"},
+		{"health check at the max", 10, "/health", true, "", ExemptClass},
+		{"admin endpoint at the max", 10, "/api/v1/admin/stats", false, "", ExemptClass},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(testConfig(), nil)
+			fill(t, s, tt.inFlight)
+
+			ticket := s.Admit(tt.path, tt.anonymous)
+			if ticket.Reason != tt.want || ticket.Class != tt.class {
+				t.Errorf("decision = %q in class %s, want %q in class %s", ticket.Reason, ticket.Class, tt.want, tt.class)
+			}
+			if want := int64(tt.inFlight); !ticket.Admitted() && s.InFlight() != want {
+				t.Errorf("in flight = %d after shedding, want %d", s.InFlight(), want)
+			}
+		})
+	}
+}
+
+func TestDoneReleasesInFlight(t *testing.T) {
+	s := New(testConfig(), nil)
+	tickets := fill(t, s, 10)
+	if s.Admit("/api/v1/responses", false).Admitted() {
+		t.Fatal("request past the max was admitted")
+	}
+	tickets[0].Done()
+	if !s.Admit("/api/v1/responses", false).Admitted() {
+		t.Error("request was shed after another completed")
+	}
+	// Exempt requests are not counted
+	s.Admit("/health", false).Done()
+	if s.InFlight() != 10 {
+		t.Errorf("in flight = %d, want 10", s.InFlight())
+	}
+}
+
+func TestAdmitLatencyTargets(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := New(testConfig(), nil)
+	s.now = func() time.Time { return now }
+
+	observe := func(path string, n int, latency time.Duration) {
+		for i := 0; i < n; i++ {
+			ticket := s.Admit(path, false)
+			if !ticket.Admitted() {
+				t.Fatalf("request to %s was shed: %s", path, ticket.Reason)
+			}
+			now = now.Add(latency)
+			ticket.Done()
+		}
+	}
+	// Reports run at 3x their target, submissions at 1.5x theirs
+	observe("/api/v1/reports/r-1", 30, 300*time.Millisecond)
+	observe("/api/v1/responses", 30, 150*time.Millisecond)
+	s.evaluate(now)
+
+	tests := []struct {
+		path      string
+		anonymous bool
+		want      Reason
+	}{
+		{"/api/v1/reports/r-1", false, ReasonLatency},
+		{"/api/v1/responses", true, ReasonLatency},
+		{"/api/v1/responses", false, ""},
+		{"/api/v1/forms", true, ""},
+	}
+	for _, tt := range tests {
+		if ticket := s.Admit(tt.path, tt.anonymous); ticket.Reason != tt.want {
+			t.Errorf("%s (anonymous %v): decision = %q, want %q", tt.path, tt.anonymous, ticket.Reason, tt.want)
+		} else {
+			ticket.Done()
+		}
+	}
+
+	// The pressure lifts once the slow requests have left the window
+	now = now.Add(31 * time.Second)
+	s.evaluate(now)
+	if ticket := s.Admit("/api/v1/reports/r-1", true); !ticket.Admitted() {
+		t.Errorf("reports still shed after the window: %s", ticket.Reason)
+	}
+}
+
+func TestShedRequestsMetric(t *testing.T) {
+	collector := metrics.NewCollector(metrics.DefaultConfig())
+	s := New(testConfig(), collector)
+	fill(t, s, 7)
+	s.Admit("/api/v1/reports/r-1", false)
+	s.Admit("/api/v1/forms", true)
+	s.Admit("/api/v1/forms", true)
+
+	if got := testutil.ToFloat64(collector.ShedRequests.WithLabelValues("in_flight", "reports")); got != 1 {
+		t.Errorf("reports shed = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(collector.ShedRequests.WithLabelValues("in_flight", DefaultClass)); got != 2 {
+		t.Errorf("default class shed = %v, want 2", got)
+	}
+}
+
+func TestWrapRejectsWithRetryAfter(t *testing.T) {
+	s := New(testConfig(), nil)
+	fill(t, s, 10)
+	handler := s.Wrap(http.NotFoundHandler(), func(*http.Request) bool { return false })
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/forms", nil))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "2" {
+		t.Errorf("status %d with Retry-After %q, want 503 with 2", rec.Code, rec.Header().Get("Retry-After"))
+	}
+}
+
+// TestLoadSlowUpstream drives a gateway in front of an upstream that serves
+// two requests at a time, slowed to 20ms each, with 40 concurrent clients.
+// Without shedding the requests queue at the upstream and wait for each
+// other; with it the latency of the admitted requests stays near the
+// service time.
+func TestLoadSlowUpstream(t *testing.T) {
+	if testing.Short() {
+		t.Skip("load test")
+	}
+
+	const (
+		clients     = 40
+		capacity    = 2
+		serviceTime = 20 * time.Millisecond
+		duration    = time.Second
+	)
+	slots := make(chan struct{}, capacity)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		slots <- struct{}{}
+		time.Sleep(serviceTime)
+		<-slots
+		io.WriteString(w, "ok")
+	}))
+	defer upstream.Close()
+
+	transport := &http.Transport{MaxIdleConnsPerHost: clients}
+	defer transport.CloseIdleConnections()
+	proxy := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp, err := (&http.Client{Transport: transport}).Get(upstream.URL)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		io.Copy(w, resp.Body)
+	})
+
+	run := func(handler http.Handler) (p99 time.Duration, admitted, shed int) {
+		gateway := httptest.NewServer(handler)
+		defer gateway.Close()
+		client := &http.Client{Transport: &http.Transport{MaxIdleConnsPerHost: clients}}
+
+		var mu sync.Mutex
+		var latencies []time.Duration
+		var wg sync.WaitGroup
+		deadline := time.Now().Add(duration)
+		for i := 0; i < clients; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for time.Now().Before(deadline) {
+					start := time.Now()
+					resp, err := client.Get(gateway.URL + "/api/v1/forms")
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					io.Copy(io.Discard, resp.Body)
+					resp.Body.Close()
+					elapsed := time.Since(start)
+
+					mu.Lock()
+					if resp.StatusCode == http.StatusServiceUnavailable {
+						shed++
+					} else {
+						latencies = append(latencies, elapsed)
+					}
+					mu.Unlock()
+					if resp.StatusCode == http.StatusServiceUnavailable {
+						// Clients back off as Retry-After asks, if briefly
+						time.Sleep(5 * time.Millisecond)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		if len(latencies) == 0 {
+			t.Fatal("no request was admitted")
+		}
+		return latencies[len(latencies)*99/100], len(latencies), shed
+	}
+
+	unshedP99, _, _ := run(proxy)
+
+	cfg := testConfig()
+	cfg.MaxInFlight, cfg.AnonymousInFlight, cfg.NonCriticalInFlight = 2*capacity, 0, 0
+	shedP99, admitted, shed := run(New(cfg, nil).Wrap(proxy, func(*http.Request) bool { return false }))
+
+	t.Logf("p99 without shedding %s; with shedding %s over %d admitted and %d shed requests", unshedP99, shedP99, admitted, shed)
+	if shed == 0 {
+		t.Error("no request was shed")
+	}
+	if bound := 5 * serviceTime; shedP99 > bound {
+		t.Errorf("p99 of admitted requests = %s, want under %s", shedP99, bound)
+	}
+	if shedP99 >= unshedP99 {
+		t.Errorf("p99 with shedding %s is not below the %s without", shedP99, unshedP99)
+	}
+}
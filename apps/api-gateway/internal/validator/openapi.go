@@ -0,0 +1,607 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OpenAPIValidator validates requests against the operations of a Swagger 2.0
+// document. Schemas are compiled once when the validator is created.
+type OpenAPIValidator struct {
+	routes []*openAPIRoute
+}
+
+// openAPIRoute is one path template with the operations defined on it
+type openAPIRoute struct {
+	template   string
+	segments   []string
+	literals   int
+	operations map[string]*openAPIOperation
+}
+
+type openAPIOperation struct {
+	parameters []*openAPIParameter
+	body       *openAPIParameter
+}
+
+type openAPIParameter struct {
+	name     string
+	in       string
+	required bool
+	schema   *compiledSchema
+	// collectionFormat of array query parameters
+	collection string
+}
+
+// compiledSchema is a JSON schema with references resolved and patterns compiled
+type compiledSchema struct {
+	typ                  string
+	format               string
+	required             []string
+	properties           map[string]*compiledSchema
+	additionalProperties *bool
+	items                *compiledSchema
+	allOf                []*compiledSchema
+	enum                 []interface{}
+	minimum              *float64
+	maximum              *float64
+	minLength            *int
+	maxLength            *int
+	minItems             *int
+	maxItems             *int
+	pattern              *regexp.Regexp
+}
+
+// Swagger 2.0 document structures, limited to what validation needs
+type swaggerDoc struct {
+	BasePath    string                                `json:"basePath"`
+	Paths       map[string]map[string]json.RawMessage `json:"paths"`
+	Definitions map[string]*swaggerSchema             `json:"definitions"`
+}
+
+type swaggerOperation struct {
+	Parameters []swaggerParameter `json:"parameters"`
+}
+
+type swaggerParameter struct {
+	Name             string         `json:"name"`
+	In               string         `json:"in"`
+	Required         bool           `json:"required"`
+	Schema           *swaggerSchema `json:"schema"`
+	CollectionFormat string         `json:"collectionFormat"`
+	swaggerSchema
+}
+
+type swaggerSchema struct {
+	Ref                  string                    `json:"$ref"`
+	Type                 string                    `json:"type"`
+	Format               string                    `json:"format"`
+	Required             []string                  `json:"required"`
+	Properties           map[string]*swaggerSchema `json:"properties"`
+	AdditionalProperties json.RawMessage           `json:"additionalProperties"`
+	Items                *swaggerSchema            `json:"items"`
+	AllOf                []*swaggerSchema          `json:"allOf"`
+	Enum                 []interface{}             `json:"enum"`
+	Minimum              *float64                  `json:"minimum"`
+	Maximum              *float64                  `json:"maximum"`
+	MinLength            *int                      `json:"minLength"`
+	MaxLength            *int                      `json:"maxLength"`
+	MinItems             *int                      `json:"minItems"`
+	MaxItems             *int                      `json:"maxItems"`
+	Pattern              string                    `json:"pattern"`
+}
+
+var (
+	uuidPattern     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	emailPattern    = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+	dateTimePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})$`)
+)
+
+// NewOpenAPIValidator compiles every operation of a Swagger 2.0 document
+func NewOpenAPIValidator(document []byte) (*OpenAPIValidator, error) {
+	var doc swaggerDoc
+	if err := json.Unmarshal(document, &doc); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+
+	c := &schemaCompiler{definitions: doc.Definitions, compiled: make(map[string]*compiledSchema)}
+	basePath := strings.TrimSuffix(doc.BasePath, "/")
+
+	v := &OpenAPIValidator{}
+	for template, methods := range doc.Paths {
+		route := &openAPIRoute{
+			template:   basePath + template,
+			segments:   splitPath(basePath + template),
+			operations: make(map[string]*openAPIOperation),
+		}
+		for _, segment := range route.segments {
+			if !isPathParam(segment) {
+				route.literals++
+			}
+		}
+
+		var shared []swaggerParameter
+		if raw, ok := methods["parameters"]; ok {
+			if err := json.Unmarshal(raw, &shared); err != nil {
+				return nil, fmt.Errorf("invalid parameters of %s: %w", template, err)
+			}
+		}
+
+		for method, raw := range methods {
+			if method == "parameters" {
+				continue
+			}
+			var op swaggerOperation
+			if err := json.Unmarshal(raw, &op); err != nil {
+				return nil, fmt.Errorf("invalid operation %s %s: %w", method, template, err)
+			}
+
+			compiled, err := c.operation(append(append([]swaggerParameter{}, shared...), op.Parameters...))
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", strings.ToUpper(method), template, err)
+			}
+			route.operations[strings.ToUpper(method)] = compiled
+		}
+
+		v.routes = append(v.routes, route)
+	}
+
+	// Prefer routes with more literal segments: /forms/public beats /forms/{id}
+	sort.Slice(v.routes, func(i, j int) bool {
+		if v.routes[i].literals != v.routes[j].literals {
+			return v.routes[i].literals > v.routes[j].literals
+		}
+		return v.routes[i].template < v.routes[j].template
+	})
+
+	return v, nil
+}
+
+// Match reports whether the request maps to an operation in the document
+func (v *OpenAPIValidator) Match(r *http.Request) bool {
+	op, _ := v.match(r)
+	return op != nil
+}
+
+// Validate checks the request's parameters and JSON body against its
+// operation. Requests that match no operation are not validated. The body is
+// restored so it can still be proxied.
+func (v *OpenAPIValidator) Validate(r *http.Request) ValidationErrors {
+	op, pathParams := v.match(r)
+	if op == nil {
+		return nil
+	}
+
+	var errs ValidationErrors
+	query := r.URL.Query()
+	for _, param := range op.parameters {
+		var values []string
+		switch param.in {
+		case "path":
+			values = []string{pathParams[param.name]}
+		case "query":
+			values = query[param.name]
+		case "header":
+			values = r.Header.Values(param.name)
+		default:
+			continue
+		}
+
+		field := param.in + "." + param.name
+		if len(values) == 0 || (len(values) == 1 && values[0] == "" && param.in != "path") {
+			if param.required {
+				errs = append(errs, ValidationError{Field: field, Message: "is required"})
+			}
+			continue
+		}
+
+		value, err := parseParameter(param, values)
+		if err != nil {
+			errs = append(errs, ValidationError{Field: field, Message: err.Error(), Value: values[0]})
+			continue
+		}
+		errs = param.schema.validate(field, value, errs)
+	}
+
+	if op.body != nil {
+		errs = v.validateBody(r, op.body, errs)
+	}
+
+	return errs
+}
+
+// validateBody validates JSON bodies. Other content types such as multipart
+// uploads are passed through unchecked.
+func (v *OpenAPIValidator) validateBody(r *http.Request, param *openAPIParameter, errs ValidationErrors) ValidationErrors {
+	if r.Body == nil || r.Body == http.NoBody {
+		if param.required {
+			errs = append(errs, ValidationError{Field: "body", Message: "is required"})
+		}
+		return errs
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "" && mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+		return errs
+	}
+
+	data, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		return append(errs, ValidationError{Field: "body", Message: "could not be read"})
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		if param.required {
+			errs = append(errs, ValidationError{Field: "body", Message: "is required"})
+		}
+		return errs
+	}
+
+	var body interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return append(errs, ValidationError{Field: "body", Message: "is not valid JSON"})
+	}
+	return param.schema.validate("body", body, errs)
+}
+
+// match finds the operation for the request and extracts its path parameters
+func (v *OpenAPIValidator) match(r *http.Request) (*openAPIOperation, map[string]string) {
+	segments := splitPath(r.URL.Path)
+
+	for _, route := range v.routes {
+		if len(route.segments) != len(segments) {
+			continue
+		}
+		op, ok := route.operations[r.Method]
+		if !ok {
+			continue
+		}
+
+		var params map[string]string
+		matched := true
+		for i, segment := range route.segments {
+			if isPathParam(segment) {
+				if params == nil {
+					params = make(map[string]string)
+				}
+				params[segment[1:len(segment)-1]] = segments[i]
+				continue
+			}
+			if segment != segments[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return op, params
+		}
+	}
+
+	return nil, nil
+}
+
+// validate appends every violation of value against the schema to errs
+func (s *compiledSchema) validate(field string, value interface{}, errs ValidationErrors) ValidationErrors {
+	if s == nil {
+		return errs
+	}
+
+	for _, sub := range s.allOf {
+		errs = sub.validate(field, value, errs)
+	}
+
+	if value == nil {
+		if s.typ != "" {
+			errs = append(errs, ValidationError{Field: field, Message: "must not be null"})
+		}
+		return errs
+	}
+
+	switch s.typ {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return append(errs, ValidationError{Field: field, Message: "must be an object"})
+		}
+		for _, name := range s.required {
+			if _, ok := obj[name]; !ok {
+				errs = append(errs, ValidationError{Field: field + "." + name, Message: "is required"})
+			}
+		}
+		for name, prop := range obj {
+			if schema, ok := s.properties[name]; ok {
+				errs = schema.validate(field+"."+name, prop, errs)
+			} else if s.additionalProperties != nil && !*s.additionalProperties {
+				errs = append(errs, ValidationError{Field: field + "." + name, Message: "is not allowed"})
+			}
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return append(errs, ValidationError{Field: field, Message: "must be an array"})
+		}
+		if s.minItems != nil && len(items) < *s.minItems {
+			errs = append(errs, ValidationError{Field: field, Message: fmt.Sprintf("must have at least %d items", *s.minItems)})
+		}
+		if s.maxItems != nil && len(items) > *s.maxItems {
+			errs = append(errs, ValidationError{Field: field, Message: fmt.Sprintf("must have at most %d items", *s.maxItems)})
+		}
+		for i, item := range items {
+			errs = s.items.validate(field+"["+strconv.Itoa(i)+"]", item, errs)
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return append(errs, ValidationError{Field: field, Message: "must be a string"})
+		}
+		length := len([]rune(str))
+		if s.minLength != nil && length < *s.minLength {
+			errs = append(errs, ValidationError{Field: field, Message: fmt.Sprintf("must be at least %d characters", *s.minLength)})
+		}
+		if s.maxLength != nil && length > *s.maxLength {
+			errs = append(errs, ValidationError{Field: field, Message: fmt.Sprintf("must be at most %d characters", *s.maxLength)})
+		}
+		if s.pattern != nil && !s.pattern.MatchString(str) {
+			errs = append(errs, ValidationError{Field: field, Message: "does not match the required pattern"})
+		}
+		if msg := checkFormat(s.format, str); msg != "" {
+			errs = append(errs, ValidationError{Field: field, Message: msg})
+		}
+	case "integer", "number":
+		num, ok := value.(float64)
+		if !ok {
+			return append(errs, ValidationError{Field: field, Message: "must be a " + s.typ})
+		}
+		if s.typ == "integer" && num != math.Trunc(num) {
+			return append(errs, ValidationError{Field: field, Message: "must be an integer"})
+		}
+		if s.minimum != nil && num < *s.minimum {
+			errs = append(errs, ValidationError{Field: field, Message: fmt.Sprintf("must be at least %v", *s.minimum)})
+		}
+		if s.maximum != nil && num > *s.maximum {
+			errs = append(errs, ValidationError{Field: field, Message: fmt.Sprintf("must be at most %v", *s.maximum)})
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return append(errs, ValidationError{Field: field, Message: "must be a boolean"})
+		}
+	}
+
+	if len(s.enum) > 0 && !inEnum(s.enum, value) {
+		errs = append(errs, ValidationError{Field: field, Message: "is not one of the allowed values"})
+	}
+
+	return errs
+}
+
+// schemaCompiler resolves references against the document's definitions.
+// Each definition is compiled once, which also makes recursive schemas safe.
+type schemaCompiler struct {
+	definitions map[string]*swaggerSchema
+	compiled    map[string]*compiledSchema
+}
+
+func (c *schemaCompiler) operation(params []swaggerParameter) (*openAPIOperation, error) {
+	op := &openAPIOperation{}
+	for i := range params {
+		p := params[i]
+
+		var schema *compiledSchema
+		var err error
+		if p.In == "body" {
+			schema, err = c.schema(p.Schema)
+		} else {
+			schema, err = c.schema(&p.swaggerSchema)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parameter %s: %w", p.Name, err)
+		}
+
+		param := &openAPIParameter{
+			name:       p.Name,
+			in:         p.In,
+			required:   p.Required || p.In == "path",
+			schema:     schema,
+			collection: p.CollectionFormat,
+		}
+		if p.In == "body" {
+			op.body = param
+		} else {
+			op.parameters = append(op.parameters, param)
+		}
+	}
+	return op, nil
+}
+
+func (c *schemaCompiler) schema(s *swaggerSchema) (*compiledSchema, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	if s.Ref != "" {
+		name := strings.TrimPrefix(s.Ref, "#/definitions/")
+		if compiled, ok := c.compiled[name]; ok {
+			return compiled, nil
+		}
+		def, ok := c.definitions[name]
+		if !ok {
+			return nil, fmt.Errorf("unresolved reference %s", s.Ref)
+		}
+		compiled := &compiledSchema{}
+		c.compiled[name] = compiled
+		resolved, err := c.schema(def)
+		if err != nil {
+			return nil, err
+		}
+		*compiled = *resolved
+		return compiled, nil
+	}
+
+	compiled := &compiledSchema{
+		typ:       s.Type,
+		format:    s.Format,
+		required:  s.Required,
+		enum:      s.Enum,
+		minimum:   s.Minimum,
+		maximum:   s.Maximum,
+		minLength: s.MinLength,
+		maxLength: s.MaxLength,
+		minItems:  s.MinItems,
+		maxItems:  s.MaxItems,
+	}
+	if compiled.typ == "" && len(s.Properties) > 0 {
+		compiled.typ = "object"
+	}
+
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", s.Pattern, err)
+		}
+		compiled.pattern = pattern
+	}
+
+	if len(s.AdditionalProperties) > 0 {
+		var allowed bool
+		if err := json.Unmarshal(s.AdditionalProperties, &allowed); err == nil {
+			compiled.additionalProperties = &allowed
+		}
+	}
+
+	if len(s.Properties) > 0 {
+		compiled.properties = make(map[string]*compiledSchema, len(s.Properties))
+		for name, prop := range s.Properties {
+			sub, err := c.schema(prop)
+			if err != nil {
+				return nil, fmt.Errorf("property %s: %w", name, err)
+			}
+			compiled.properties[name] = sub
+		}
+	}
+
+	var err error
+	if compiled.items, err = c.schema(s.Items); err != nil {
+		return nil, err
+	}
+	for _, sub := range s.AllOf {
+		part, err := c.schema(sub)
+		if err != nil {
+			return nil, err
+		}
+		compiled.allOf = append(compiled.allOf, part)
+	}
+
+	return compiled, nil
+}
+
+// parseParameter converts raw path, query or header values to the JSON types
+// the parameter schema expects
+func parseParameter(param *openAPIParameter, values []string) (interface{}, error) {
+	if param.schema == nil {
+		return values[0], nil
+	}
+
+	if param.schema.typ == "array" {
+		raw := values
+		if len(values) == 1 {
+			separator := ","
+			switch param.collection {
+			case "ssv":
+				separator = " "
+			case "tsv":
+				separator = "\t"
+			case "pipes":
+				separator = "|"
+			case "multi":
+				separator = ""
+			}
+			if separator != "" {
+				raw = strings.Split(values[0], separator)
+			}
+		}
+		items := make([]interface{}, 0, len(raw))
+		for _, value := range raw {
+			item, err := parseScalar(param.schema.items, value)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	}
+
+	return parseScalar(param.schema, values[0])
+}
+
+func parseScalar(schema *compiledSchema, value string) (interface{}, error) {
+	if schema == nil {
+		return value, nil
+	}
+	switch schema.typ {
+	case "integer":
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("must be an integer")
+		}
+		return float64(n), nil
+	case "number":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("must be a number")
+		}
+		return n, nil
+	case "boolean":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("must be a boolean")
+		}
+		return b, nil
+	default:
+		return value, nil
+	}
+}
+
+func checkFormat(format, value string) string {
+	switch format {
+	case "uuid":
+		if !uuidPattern.MatchString(value) {
+			return "must be a UUID"
+		}
+	case "email":
+		if !emailPattern.MatchString(value) {
+			return "must be an email address"
+		}
+	case "date-time":
+		if !dateTimePattern.MatchString(value) {
+			return "must be an RFC 3339 date-time"
+		}
+	}
+	return ""
+}
+
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, allowed := range enum {
+		if allowed == value {
+			return true
+		}
+	}
+	return false
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+func isPathParam(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
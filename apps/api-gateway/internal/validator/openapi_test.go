@@ -0,0 +1,148 @@
+package validator
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testSpec = `{
+  "swagger": "2.0",
+  "basePath": "/api/v1",
+  "paths": {
+    "/forms": {
+      "get": {
+        "parameters": [
+          {"name": "page", "in": "query", "type": "integer", "minimum": 1},
+          {"name": "status", "in": "query", "type": "string", "enum": ["draft", "published"]}
+        ]
+      },
+      "post": {
+        "parameters": [
+          {"name": "form", "in": "body", "required": true, "schema": {"$ref": "#/definitions/Form"}}
+        ]
+      }
+    },
+    "/forms/{id}": {
+      "parameters": [
+        {"name": "id", "in": "path", "required": true, "type": "string", "format": "uuid"}
+      ],
+      "get": {}
+    },
+    "/forms/public": {
+      "get": {}
+    }
+  },
+  "definitions": {
+    "Form": {
+      "type": "object",
+      "required": ["title", "questions"],
+      "properties": {
+        "title": {"type": "string", "minLength": 1, "maxLength": 200},
+        "questions": {"type": "array", "minItems": 1, "items": {"$ref": "#/definitions/Question"}}
+      }
+    },
+    "Question": {
+      "type": "object",
+      "required": ["type"],
+      "properties": {
+        "type": {"type": "string", "enum": ["text", "choice"]},
+        "followUp": {"$ref": "#/definitions/Question"}
+      }
+    }
+  }
+}`
+
+func newTestValidator(t testing.TB) *OpenAPIValidator {
+	t.Helper()
+	v, err := NewOpenAPIValidator([]byte(testSpec))
+	if err != nil {
+		t.Fatalf("failed to compile spec: %v", err)
+	}
+	return v
+}
+
+func TestOpenAPIValidator(t *testing.T) {
+	v := newTestValidator(t)
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		body        string
+		violations  []string
+	}{
+		{name: "valid query", method: http.MethodGet, target: "/api/v1/forms?page=2&status=draft"},
+		{name: "bad query types", method: http.MethodGet, target: "/api/v1/forms?page=abc&status=archived", violations: []string{"query.page", "query.status"}},
+		{name: "query below minimum", method: http.MethodGet, target: "/api/v1/forms?page=0", violations: []string{"query.page"}},
+		{name: "valid path param", method: http.MethodGet, target: "/api/v1/forms/0b8f6e1c-8f7a-4d47-9c1e-2f3c6a1b9d10"},
+		{name: "bad path param", method: http.MethodGet, target: "/api/v1/forms/42", violations: []string{"path.id"}},
+		{name: "literal segment wins", method: http.MethodGet, target: "/api/v1/forms/public"},
+		{name: "unknown route falls through", method: http.MethodGet, target: "/api/v1/unknown/route"},
+		{name: "undocumented method falls through", method: http.MethodDelete, target: "/api/v1/forms"},
+		{
+			name: "valid body", method: http.MethodPost, target: "/api/v1/forms", contentType: "application/json",
+			body: `{"title": "Survey", "questions": [{"type": "text", "followUp": {"type": "choice"}}]}`,
+		},
+		{
+			name: "invalid body", method: http.MethodPost, target: "/api/v1/forms", contentType: "application/json",
+			body:       `{"title": "", "questions": [{"type": "rating", "followUp": {}}]}`,
+			violations: []string{"body.title", "body.questions[0].type", "body.questions[0].followUp.type"},
+		},
+		{name: "missing body", method: http.MethodPost, target: "/api/v1/forms", contentType: "application/json", violations: []string{"body"}},
+		{name: "malformed body", method: http.MethodPost, target: "/api/v1/forms", contentType: "application/json", body: `{"title":`, violations: []string{"body"}},
+		{name: "multipart skipped", method: http.MethodPost, target: "/api/v1/forms", contentType: "multipart/form-data; boundary=x", body: "--x--"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.body == "" {
+				req.Body = http.NoBody
+			}
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+
+			errs := v.Validate(req)
+
+			got := make(map[string]bool, len(errs))
+			for _, err := range errs {
+				got[err.Field] = true
+			}
+			if len(errs) != len(tt.violations) {
+				t.Fatalf("expected violations %v, got %v", tt.violations, errs)
+			}
+			for _, field := range tt.violations {
+				if !got[field] {
+					t.Errorf("expected a violation for %s, got %v", field, errs)
+				}
+			}
+
+			if tt.body != "" {
+				body, _ := io.ReadAll(req.Body)
+				if string(body) != tt.body {
+					t.Errorf("body was not restored: %q", body)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkOpenAPIValidator(b *testing.B) {
+	v := newTestValidator(b)
+	body := `{"title": "Customer survey", "questions": [{"type": "text"}, {"type": "choice", "followUp": {"type": "text"}}]}`
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/forms", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if errs := v.Validate(req); len(errs) != 0 {
+			b.Fatalf("unexpected violations: %v", errs)
+		}
+	}
+}
@@ -4,6 +4,7 @@ package metrics
 
 import (
 	"net/http"
+	"runtime"
 	"strconv"
 	"time"
 
@@ -32,6 +33,15 @@ type Collector struct {
 	UpstreamRequests *prometheus.CounterVec
 	UpstreamLatency  *prometheus.HistogramVec
 	UpstreamErrors   *prometheus.CounterVec
+	// CoalescedRequests counts requests served by another request's upstream call
+	CoalescedRequests *prometheus.CounterVec
+	// CachedResponses counts the requests of cached routes by cache result
+	CachedResponses *prometheus.CounterVec
+	// CacheInvalidations counts the form invalidations received by result,
+	// and CacheInvalidationLatency measures the time from the change of a
+	// form to the purge of its cached views
+	CacheInvalidations       *prometheus.CounterVec
+	CacheInvalidationLatency prometheus.Histogram
 
 	// Circuit breaker metrics
 	CircuitBreakerState *prometheus.GaugeVec
@@ -51,7 +61,19 @@ type Collector struct {
 	ErrorsTotal *prometheus.CounterVec
 	PanicsTotal *prometheus.CounterVec
 
+	// Audit metrics
+	AuditEvents      *prometheus.CounterVec
+	AuditBufferDepth prometheus.Gauge
+
+	// Load shedding metrics
+	ShedRequests *prometheus.CounterVec
+
+	// FaultsInjected counts the faults injected into upstream calls for
+	// resilience testing
+	FaultsInjected *prometheus.CounterVec
+
 	registry *prometheus.Registry
+	window   *requestWindow
 }
 
 // Config holds metrics configuration
@@ -105,6 +127,7 @@ func NewCollector(config Config) *Collector {
 
 	collector := &Collector{
 		registry: registry,
+		window:   newRequestWindow(histogramBuckets),
 
 		// HTTP metrics
 		RequestsTotal: prometheus.NewCounterVec(
@@ -114,7 +137,7 @@ func NewCollector(config Config) *Collector {
 				Name:      "http_requests_total",
 				Help:      "Total number of HTTP requests",
 			},
-			[]string{"method", "path", "status_code"},
+			[]string{"method", "path", "status_code", "fault_injected"},
 		),
 
 		RequestDuration: prometheus.NewHistogramVec(
@@ -125,7 +148,7 @@ func NewCollector(config Config) *Collector {
 				Help:      "HTTP request duration in seconds",
 				Buckets:   histogramBuckets,
 			},
-			[]string{"method", "path", "status_code"},
+			[]string{"method", "path", "status_code", "fault_injected"},
 		),
 
 		ResponseSize: prometheus.NewHistogramVec(
@@ -136,7 +159,7 @@ func NewCollector(config Config) *Collector {
 				Help:      "HTTP response size in bytes",
 				Buckets:   sizeBuckets,
 			},
-			[]string{"method", "path", "status_code"},
+			[]string{"method", "path", "status_code", "fault_injected"},
 		),
 
 		RequestsInFlight: prometheus.NewGauge(
@@ -233,6 +256,43 @@ func NewCollector(config Config) *Collector {
 			[]string{"service", "error_type"},
 		),
 
+		CoalescedRequests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: config.Namespace,
+				Subsystem: config.Subsystem,
+				Name:      "coalesced_requests_total",
+				Help:      "Total number of requests served by the upstream call of an identical concurrent request",
+			},
+			[]string{"service"},
+		),
+		CachedResponses: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: config.Namespace,
+				Subsystem: config.Subsystem,
+				Name:      "cached_responses_total",
+				Help:      "Total number of requests of cached routes by result: HIT, REVALIDATED or MISS",
+			},
+			[]string{"service", "result"},
+		),
+		CacheInvalidations: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: config.Namespace,
+				Subsystem: config.Subsystem,
+				Name:      "cache_invalidations_total",
+				Help:      "Total number of form cache invalidations received by result: purged, coalesced or invalid",
+			},
+			[]string{"result"},
+		),
+		CacheInvalidationLatency: prometheus.NewHistogram(
+			prometheus.HistogramOpts{
+				Namespace: config.Namespace,
+				Subsystem: config.Subsystem,
+				Name:      "cache_invalidation_latency_seconds",
+				Help:      "Time from the change of a form to the purge of its cached views in seconds",
+				Buckets:   []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+			},
+		),
+
 		// Circuit breaker metrics
 		CircuitBreakerState: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
@@ -332,6 +392,47 @@ func NewCollector(config Config) *Collector {
 			},
 			[]string{"component"},
 		),
+
+		// Audit metrics
+		AuditEvents: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: config.Namespace,
+				Subsystem: config.Subsystem,
+				Name:      "audit_events_total",
+				Help:      "Total number of audit events by outcome (delivered, failed, dropped)",
+			},
+			[]string{"outcome"},
+		),
+		AuditBufferDepth: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: config.Namespace,
+				Subsystem: config.Subsystem,
+				Name:      "audit_buffer_depth",
+				Help:      "Number of audit events waiting to be published",
+			},
+		),
+
+		// Load shedding metrics
+		ShedRequests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: config.Namespace,
+				Subsystem: config.Subsystem,
+				Name:      "shed_requests_total",
+				Help:      "Total number of requests rejected by load shedding, by reason (in_flight, latency) and route class",
+			},
+			[]string{"reason", "route_class"},
+		),
+
+		// Fault injection metrics
+		FaultsInjected: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: config.Namespace,
+				Subsystem: config.Subsystem,
+				Name:      "faults_injected_total",
+				Help:      "Total number of faults injected into upstream calls, by service and fault type",
+			},
+			[]string{"service", "type"},
+		),
 	}
 
 	// Register metrics
@@ -363,6 +464,10 @@ func (c *Collector) registerMetrics(config Config) {
 	c.registry.MustRegister(c.UpstreamRequests)
 	c.registry.MustRegister(c.UpstreamLatency)
 	c.registry.MustRegister(c.UpstreamErrors)
+	c.registry.MustRegister(c.CoalescedRequests)
+	c.registry.MustRegister(c.CachedResponses)
+	c.registry.MustRegister(c.CacheInvalidations)
+	c.registry.MustRegister(c.CacheInvalidationLatency)
 
 	// Register circuit breaker metrics
 	c.registry.MustRegister(c.CircuitBreakerState)
@@ -382,6 +487,16 @@ func (c *Collector) registerMetrics(config Config) {
 	c.registry.MustRegister(c.ErrorsTotal)
 	c.registry.MustRegister(c.PanicsTotal)
 
+	// Register audit metrics
+	c.registry.MustRegister(c.AuditEvents)
+	c.registry.MustRegister(c.AuditBufferDepth)
+
+	// Register load shedding metrics
+	c.registry.MustRegister(c.ShedRequests)
+
+	// Register fault injection metrics
+	c.registry.MustRegister(c.FaultsInjected)
+
 	// Register Go metrics if enabled
 	if config.EnableGoMetrics {
 		c.registry.MustRegister(prometheus.NewGoCollector())
@@ -403,11 +518,20 @@ func (c *Collector) Handler() http.Handler {
 
 // RecordHTTPRequest records HTTP request metrics
 func (c *Collector) RecordHTTPRequest(method, path string, statusCode int, duration time.Duration, responseSize int64) {
+	c.RecordHTTPRequestWithFault(method, path, statusCode, duration, responseSize, false)
+}
+
+// RecordHTTPRequestWithFault records HTTP request metrics, labelled
+// fault_injected="true" for the requests a fault was injected into so
+// dashboards can filter them
+func (c *Collector) RecordHTTPRequestWithFault(method, path string, statusCode int, duration time.Duration, responseSize int64, faultInjected bool) {
 	statusStr := strconv.Itoa(statusCode)
+	faultStr := strconv.FormatBool(faultInjected)
 
-	c.RequestsTotal.WithLabelValues(method, path, statusStr).Inc()
-	c.RequestDuration.WithLabelValues(method, path, statusStr).Observe(duration.Seconds())
-	c.ResponseSize.WithLabelValues(method, path, statusStr).Observe(float64(responseSize))
+	c.RequestsTotal.WithLabelValues(method, path, statusStr, faultStr).Inc()
+	c.RequestDuration.WithLabelValues(method, path, statusStr, faultStr).Observe(duration.Seconds())
+	c.ResponseSize.WithLabelValues(method, path, statusStr, faultStr).Observe(float64(responseSize))
+	c.window.observe(time.Now(), statusCode, duration)
 }
 
 // IncrementRequestsInFlight increments in-flight requests counter
@@ -453,6 +577,37 @@ func (c *Collector) RecordUpstreamError(service, errorType string) {
 	c.UpstreamErrors.WithLabelValues(service, errorType).Inc()
 }
 
+// RecordCoalescedRequest records a request served by another request's upstream call
+func (c *Collector) RecordCoalescedRequest(service string) {
+	c.CoalescedRequests.WithLabelValues(service).Inc()
+}
+
+// RecordCachedResponse records the result of the response cache for a request
+func (c *Collector) RecordCachedResponse(service, result string) {
+	c.CachedResponses.WithLabelValues(service, result).Inc()
+}
+
+// RecordCacheInvalidation records a form invalidation received
+func (c *Collector) RecordCacheInvalidation(result string) {
+	c.CacheInvalidations.WithLabelValues(result).Inc()
+}
+
+// RecordCacheInvalidationLatency records the time from the change of a form
+// to the purge of its cached views
+func (c *Collector) RecordCacheInvalidationLatency(latency time.Duration) {
+	c.CacheInvalidationLatency.Observe(latency.Seconds())
+}
+
+// RecordShedRequest records a request rejected by load shedding
+func (c *Collector) RecordShedRequest(reason, routeClass string) {
+	c.ShedRequests.WithLabelValues(reason, routeClass).Inc()
+}
+
+// RecordInjectedFault records a fault injected into an upstream call
+func (c *Collector) RecordInjectedFault(service, faultType string) {
+	c.FaultsInjected.WithLabelValues(service, faultType).Inc()
+}
+
 // SetCircuitBreakerState sets circuit breaker state
 func (c *Collector) SetCircuitBreakerState(service string, state CircuitBreakerState) {
 	c.CircuitBreakerState.WithLabelValues(service).Set(float64(state))
@@ -539,7 +694,7 @@ func (c *Collector) RecordHistogram(metricName string, value float64, labels map
 		c.UpstreamLatency.WithLabelValues(service, "health_check").Observe(value)
 	default:
 		// Use request duration as fallback
-		c.RequestDuration.WithLabelValues("unknown", "unknown", "200").Observe(value)
+		c.RequestDuration.WithLabelValues("unknown", "unknown", "200", "false").Observe(value)
 	}
 }
 
@@ -741,16 +896,11 @@ func (c *Collector) StartSystemMetricsCollector(interval time.Duration) {
 
 // collectSystemMetrics collects system metrics
 func (c *Collector) collectSystemMetrics() {
-	// This is a simplified implementation
-	// In production, you would use proper system monitoring libraries
-
-	// Memory usage (placeholder)
-	// m := &runtime.MemStats{}
-	// runtime.ReadMemStats(m)
-	// c.SetMemoryUsage(float64(m.Alloc))
+	m := &runtime.MemStats{}
+	runtime.ReadMemStats(m)
+	c.SetMemoryUsage(float64(m.Alloc))
 
-	// Goroutine count
-	// c.SetGoroutineCount(float64(runtime.NumGoroutine()))
+	c.SetGoroutineCount(float64(runtime.NumGoroutine()))
 
 	// CPU usage would require external library like gopsutil
 }
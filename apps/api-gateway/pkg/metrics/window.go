@@ -0,0 +1,169 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// MaxWindow is the longest period WindowStats can report on
+const MaxWindow = 15 * time.Minute
+
+// WindowStats summarizes the requests seen during a sliding window
+type WindowStats struct {
+	Window      time.Duration `json:"-"`
+	Requests    uint64        `json:"requests"`
+	Errors      uint64        `json:"errors"`
+	RequestRate float64       `json:"request_rate"`
+	ErrorRate   float64       `json:"error_rate"`
+	P50         float64       `json:"p50_ms"`
+	P95         float64       `json:"p95_ms"`
+	P99         float64       `json:"p99_ms"`
+}
+
+// requestWindow keeps one histogram per second for the last MaxWindow so that
+// rates and latency percentiles can be computed over a sliding window. The
+// Prometheus histograms are cumulative since start-up and can't answer that.
+type requestWindow struct {
+	mu      sync.Mutex
+	buckets []float64
+	slots   []windowSlot
+}
+
+type windowSlot struct {
+	second   int64
+	requests uint64
+	errors   uint64
+	counts   []uint64
+}
+
+func newRequestWindow(buckets []float64) *requestWindow {
+	slots := make([]windowSlot, int(MaxWindow/time.Second))
+	for i := range slots {
+		// One extra count for observations above the largest bucket
+		slots[i].counts = make([]uint64, len(buckets)+1)
+	}
+	return &requestWindow{buckets: buckets, slots: slots}
+}
+
+// observe records one request. 5xx responses count as errors.
+func (w *requestWindow) observe(now time.Time, statusCode int, duration time.Duration) {
+	second := now.Unix()
+	seconds := duration.Seconds()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	slot := &w.slots[second%int64(len(w.slots))]
+	if slot.second != second {
+		slot.second = second
+		slot.requests = 0
+		slot.errors = 0
+		for i := range slot.counts {
+			slot.counts[i] = 0
+		}
+	}
+
+	slot.requests++
+	if statusCode >= 500 {
+		slot.errors++
+	}
+
+	i := 0
+	for i < len(w.buckets) && seconds > w.buckets[i] {
+		i++
+	}
+	slot.counts[i]++
+}
+
+// stats aggregates the slots that fall inside window
+func (w *requestWindow) stats(now time.Time, window time.Duration) WindowStats {
+	if window <= 0 || window > MaxWindow {
+		window = MaxWindow
+	}
+	stats := WindowStats{Window: window}
+	counts := make([]uint64, len(w.buckets)+1)
+	oldest := now.Unix() - int64(window/time.Second)
+
+	w.mu.Lock()
+	for _, slot := range w.slots {
+		if slot.second <= oldest || slot.second > now.Unix() {
+			continue
+		}
+		stats.Requests += slot.requests
+		stats.Errors += slot.errors
+		for i, n := range slot.counts {
+			counts[i] += n
+		}
+	}
+	w.mu.Unlock()
+
+	stats.RequestRate = float64(stats.Requests) / window.Seconds()
+	if stats.Requests > 0 {
+		stats.ErrorRate = float64(stats.Errors) / float64(stats.Requests)
+	}
+	stats.P50 = w.quantile(0.50, counts, stats.Requests) * 1000
+	stats.P95 = w.quantile(0.95, counts, stats.Requests) * 1000
+	stats.P99 = w.quantile(0.99, counts, stats.Requests) * 1000
+	return stats
+}
+
+// quantile estimates the q-quantile in seconds by linear interpolation inside
+// the bucket holding the rank, the same way histogram_quantile does
+func (w *requestWindow) quantile(q float64, counts []uint64, total uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	rank := q * float64(total)
+	var cumulative uint64
+	for i, n := range counts {
+		if float64(cumulative+n) < rank {
+			cumulative += n
+			continue
+		}
+		if i == len(w.buckets) {
+			// Above the largest bucket there is no upper bound to interpolate to
+			return w.buckets[len(w.buckets)-1]
+		}
+
+		lower := 0.0
+		if i > 0 {
+			lower = w.buckets[i-1]
+		}
+		upper := w.buckets[i]
+		if n == 0 {
+			return upper
+		}
+		return lower + (upper-lower)*(rank-float64(cumulative))/float64(n)
+	}
+
+	return w.buckets[len(w.buckets)-1]
+}
+
+// WindowStats returns request rate, error rate and latency percentiles over
+// the last window (at most MaxWindow)
+func (c *Collector) WindowStats(window time.Duration) WindowStats {
+	return c.window.stats(time.Now(), window)
+}
+
+// LatencyWindow is a sliding window of request latencies, for components that
+// act on recent percentiles rather than report them
+type LatencyWindow struct {
+	window *requestWindow
+}
+
+// NewLatencyWindow creates a window estimating percentiles over buckets, in seconds
+func NewLatencyWindow(buckets []float64) *LatencyWindow {
+	return &LatencyWindow{window: newRequestWindow(buckets)}
+}
+
+// Observe records the latency of one request
+func (w *LatencyWindow) Observe(now time.Time, duration time.Duration) {
+	w.window.observe(now, 0, duration)
+}
+
+// Stats returns the request count and latency percentiles over the last
+// window (at most MaxWindow)
+func (w *LatencyWindow) Stats(now time.Time, window time.Duration) WindowStats {
+	return w.window.stats(now, window)
+}
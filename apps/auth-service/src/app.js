@@ -11,7 +11,7 @@ const cookieParser = require('cookie-parser');
 const authRoutes = require('./routes/auth');
 
 // Import configurations
-const { initializeDatabase } = require('./config/database');
+const { initializeDatabase, getPool } = require('./config/database');
 const { configurePassport } = require('./config/passport');
 
 // Initialize Express app
@@ -137,6 +137,17 @@ app.get('/health', (req, res) => {
   });
 });
 
+// User totals for the gateway's admin dashboard, not routed by the gateway
+app.get('/internal/stats', async (req, res) => {
+  try {
+    const result = await getPool().query('SELECT COUNT(*) AS total FROM users WHERE is_active');
+    res.json({ total: Number(result.rows[0].total) });
+  } catch (error) {
+    console.error('Failed to count users:', error);
+    res.status(500).json({ error: 'failed to count users' });
+  }
+});
+
 // API routes
 app.use('/auth', authRoutes);
 
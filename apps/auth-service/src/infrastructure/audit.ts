@@ -0,0 +1,119 @@
+// Audit events of logins and account lockouts
+// Published as audit.<action> events to the audit topic of the event bus, which keeps the
+// hash-chained audit log. Recording never blocks or fails a login: events wait in a bounded
+// queue and are posted in the background; events recorded while the queue is full are dropped.
+
+import { randomUUID } from 'crypto';
+
+export interface AuditEvent {
+  action: string;
+  actor: string;
+  resourceType: string;
+  resourceId: string;
+  before?: Record<string, unknown>;
+  after?: Record<string, unknown>;
+  ip?: string;
+  correlationId?: string;
+  occurredAt: Date;
+}
+
+export interface AuditConfig {
+  eventBusUrl: string;
+  topic: string;
+  bufferSize: number;
+  retryAttempts: number;
+}
+
+// Delivery counts, exposed for monitoring
+export interface AuditStats {
+  delivered: number;
+  failed: number;
+  dropped: number;
+  queued: number;
+}
+
+export class AuditPublisher {
+  private readonly queue: AuditEvent[] = [];
+  private draining = false;
+  private readonly stats = { delivered: 0, failed: 0, dropped: 0 };
+
+  constructor(private readonly config: AuditConfig) {}
+
+  // Queue an event without waiting for its delivery
+  record(event: AuditEvent): void {
+    if (this.queue.length >= this.config.bufferSize) {
+      this.stats.dropped++;
+      console.warn(`Audit queue full, dropped ${event.action} by ${event.actor}`);
+      return;
+    }
+    this.queue.push(event);
+    void this.drain();
+  }
+
+  getStats(): AuditStats {
+    return { ...this.stats, queued: this.queue.length };
+  }
+
+  private async drain(): Promise<void> {
+    if (this.draining) {
+      return;
+    }
+    this.draining = true;
+    try {
+      let event: AuditEvent | undefined;
+      while ((event = this.queue.shift()) !== undefined) {
+        await this.publish(event);
+      }
+    } finally {
+      this.draining = false;
+    }
+  }
+
+  // The event ID is kept across attempts, so a retry the event bus already accepted is logged once
+  private async publish(event: AuditEvent): Promise<void> {
+    const body = JSON.stringify({
+      id: `audit_${randomUUID()}`,
+      event_type: `audit.${event.action}`,
+      source: 'auth-service',
+      subject: event.actor,
+      topic: this.config.topic,
+      key: event.actor,
+      data: {
+        actor: event.actor,
+        resource_type: event.resourceType,
+        resource_id: event.resourceId,
+        before: event.before ?? null,
+        after: event.after ?? null,
+        ip: event.ip ?? '',
+        correlation_id: event.correlationId ?? '',
+        occurred_at: event.occurredAt.toISOString(),
+      },
+    });
+
+    const attempts = this.config.retryAttempts + 1;
+    let lastError: unknown;
+    for (let attempt = 1; attempt <= attempts; attempt++) {
+      try {
+        const response = await fetch(`${this.config.eventBusUrl.replace(/\/$/, '')}/events`, {
+          method: 'POST',
+          headers: { 'Content-Type': 'application/json' },
+          body,
+          signal: AbortSignal.timeout(5000),
+        });
+        if (response.ok) {
+          this.stats.delivered++;
+          return;
+        }
+        lastError = new Error(`event bus answered ${response.status}`);
+      } catch (error) {
+        lastError = error;
+      }
+      if (attempt < attempts) {
+        await new Promise(resolve => setTimeout(resolve, attempt * 500));
+      }
+    }
+
+    this.stats.failed++;
+    console.error(`Failed to publish audit event ${event.action} by ${event.actor}:`, lastError);
+  }
+}
@@ -12,6 +12,7 @@ import {
   MockEmailService,
 } from '../infrastructure/repositories';
 import { AuthController } from '../interface/http/auth-controller';
+import { AuditConfig, AuditPublisher } from './audit';
 import { 
   DomainEvent, 
   UserRegisteredEvent, 
@@ -41,6 +42,8 @@ export interface AuthServiceConfig {
     apiKey?: string;
     fromEmail: string;
   };
+  // Logins and lockouts are recorded to the audit log when set
+  audit?: AuditConfig;
 }
 
 // Simple event publisher implementation
@@ -78,6 +81,7 @@ export class AuthServiceContainer {
   private readonly config: AuthServiceConfig;
   private readonly pool: Pool;
   private readonly eventPublisher: EventPublisher;
+  private readonly auditPublisher?: AuditPublisher;
 
   // Repositories
   private readonly userRepository: PostgreSQLUserRepository;
@@ -113,6 +117,9 @@ export class AuthServiceContainer {
 
     // Initialize event publisher
     this.eventPublisher = new InMemoryEventPublisher();
+    if (config.audit) {
+      this.auditPublisher = new AuditPublisher(config.audit);
+    }
 
     // Initialize repositories (Infrastructure Layer)
     this.userRepository = new PostgreSQLUserRepository(this.pool);
@@ -180,15 +187,20 @@ export class AuthServiceContainer {
       }
     );
 
-    // Example: Log user login events for security monitoring
+    // Record logins to the audit log for security monitoring
     eventPublisher.subscribe(
       UserLoginEvent,
       async (event: UserLoginEvent) => {
         console.log(`User login: ${event.userId} from IP: ${event.ipAddress}`);
-        // Here you could:
-        // - Log security events
-        // - Update last login timestamp
-        // - Detect suspicious login patterns
+        this.auditPublisher?.record({
+          action: 'user.login',
+          actor: event.userId,
+          resourceType: 'user',
+          resourceId: event.userId,
+          after: { user_agent: event.userAgent },
+          ip: event.ipAddress,
+          occurredAt: event.occurredOn,
+        });
       }
     );
 
@@ -197,10 +209,14 @@ export class AuthServiceContainer {
       UserAccountLockedEvent,
       async (event: UserAccountLockedEvent) => {
         console.log(`Account locked: ${event.userId} - ${event.reason}`);
-        // Here you could:
-        // - Send security alert email
-        // - Log security incident
-        // - Trigger admin notification
+        this.auditPublisher?.record({
+          action: 'user.locked',
+          actor: event.userId,
+          resourceType: 'user',
+          resourceId: event.userId,
+          after: { reason: event.reason },
+          occurredAt: event.occurredOn,
+        });
       }
     );
   }
@@ -264,6 +280,14 @@ export function createAuthServiceContainer(): AuthServiceContainer {
       fromEmail: process.env.FROM_EMAIL || 'noreply@xform.com',
     },
   };
+  if (process.env.EVENT_BUS_URL) {
+    config.audit = {
+      eventBusUrl: process.env.EVENT_BUS_URL,
+      topic: process.env.AUDIT_TOPIC || 'audit-log',
+      bufferSize: parseInt(process.env.AUDIT_BUFFER_SIZE || '1000'),
+      retryAttempts: 3,
+    };
+  }
 
   return new AuthServiceContainer(config);
 }
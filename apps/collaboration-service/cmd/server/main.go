@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
@@ -11,6 +12,7 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/kamkaiz/x-form-backend/collaboration-service/internal/analytics"
 	"github.com/kamkaiz/x-form-backend/collaboration-service/internal/auth"
 	"github.com/kamkaiz/x-form-backend/collaboration-service/internal/config"
 	redisService "github.com/kamkaiz/x-form-backend/collaboration-service/internal/redis"
@@ -61,14 +63,30 @@ func main() {
 
 	// Initialize WebSocket hub
 	hub := websocket.NewHub(redis, authService, &cfg.WebSocket, logger)
+	hub.UseDashboards(websocket.NewDashboards(
+		auth.NewFormClient(cfg.Dashboard.FormServiceURL, cfg.Dashboard.RequestTimeout),
+		analytics.NewClient(cfg.Dashboard.AnalyticsServiceURL, cfg.Dashboard.RequestTimeout),
+		redis,
+		cfg.Dashboard.StatsInterval,
+		logger,
+	))
 
 	// Start WebSocket hub
 	ctx, cancel = context.WithCancel(context.Background())
 	defer cancel()
 	go hub.Run(ctx)
 
+	// Push the activity of forms recorded by the form service to their rooms
+	if err := hub.ForwardActivity(ctx, redis); err != nil {
+		logger.Fatal("Failed to subscribe to form activity", zap.Error(err))
+	}
+	// and the patches merged into them, so builders update live
+	if err := hub.ForwardPatches(ctx, redis); err != nil {
+		logger.Fatal("Failed to subscribe to form patches", zap.Error(err))
+	}
+
 	// Setup HTTP router
-	router := setupRoutes(hub, logger)
+	router := setupRoutes(hub, redis, logger)
 
 	// Setup HTTP server
 	server := &http.Server{
@@ -106,7 +124,7 @@ func main() {
 }
 
 // setupRoutes configures HTTP routes
-func setupRoutes(hub *websocket.Hub, logger *zap.Logger) *mux.Router {
+func setupRoutes(hub *websocket.Hub, redis *redisService.Service, logger *zap.Logger) *mux.Router {
 	router := mux.NewRouter()
 
 	// WebSocket endpoint
@@ -131,7 +149,9 @@ func setupRoutes(hub *websocket.Hub, logger *zap.Logger) *mux.Router {
 			"totalRooms": %d,
 			"activeRooms": %d,
 			"messagesPerSecond": %d,
-			"errorsPerSecond": %d
+			"errorsPerSecond": %d,
+			"resumedSessions": %d,
+			"resyncedSessions": %d
 		}`,
 			metrics.TotalConnections,
 			metrics.ActiveConnections,
@@ -139,11 +159,17 @@ func setupRoutes(hub *websocket.Hub, logger *zap.Logger) *mux.Router {
 			metrics.ActiveRooms,
 			metrics.MessagesPerSecond,
 			metrics.ErrorsPerSecond,
+			metrics.ResumedSessions,
+			metrics.ResyncedSessions,
 		)
 
 		w.Write([]byte(response))
 	}).Methods("GET")
 
+	// Privacy erasure endpoint, called by the event bus on behalf of the
+	// gateway; not routed by the gateway
+	router.HandleFunc("/internal/privacy/erasure", privacyErasureHandler(redis, logger)).Methods("POST")
+
 	// CORS middleware
 	router.Use(corsMiddleware)
 
@@ -153,6 +179,37 @@ func setupRoutes(hub *websocket.Hub, logger *zap.Logger) *mux.Router {
 	return router
 }
 
+// privacyErasureHandler removes the presence, cursors, sessions and question
+// update history of the user in the request, answering with the counts
+func privacyErasureHandler(redis *redisService.Service, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			RequestID string `json:"request_id"`
+			UserID    string `json:"user_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.UserID == "" {
+			http.Error(w, `{"error":"user_id is required"}`, http.StatusBadRequest)
+			return
+		}
+
+		counts, err := redis.EraseUser(r.Context(), req.UserID)
+		if err != nil {
+			logger.Error("Failed to erase user data",
+				zap.String("request_id", req.RequestID),
+				zap.Error(err))
+			http.Error(w, `{"error":"failed to erase user data"}`, http.StatusInternalServerError)
+			return
+		}
+		logger.Info("Erased user data",
+			zap.String("request_id", req.RequestID),
+			zap.Any("counts", counts))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{"counts": counts})
+	}
+}
+
 // corsMiddleware handles CORS headers
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
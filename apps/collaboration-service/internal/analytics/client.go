@@ -0,0 +1,62 @@
+// Package analytics reads the response statistics of forms from the
+// analytics service
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client is a client of the analytics service
+type Client struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewClient creates a client of the analytics service at baseURL
+func NewClient(baseURL string, timeout time.Duration) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+// summaryResponse is the part of the analytics summary of a form the client
+// reads
+type summaryResponse struct {
+	Data struct {
+		TotalResponses int64 `json:"total_responses"`
+	} `json:"data"`
+}
+
+// TotalResponses returns the number of responses to the form, read on behalf
+// of the user the token was issued to. The summary is read uncached, so that
+// it is current.
+func (c *Client) TotalResponses(ctx context.Context, token, formID string) (int64, error) {
+	endpoint := c.baseURL + "/analytics/" + url.PathEscape(formID) + "/summary?use_cache=false"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get analytics summary: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("analytics service returned status %d", resp.StatusCode)
+	}
+	var summary summaryResponse
+	if err := json.NewDecoder(resp.Body).Decode(&summary); err != nil {
+		return 0, fmt.Errorf("failed to decode analytics summary: %w", err)
+	}
+	return summary.Data.TotalResponses, nil
+}
@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// FormClient checks the access of users to forms against the form service,
+// which allows the owners and collaborators of a form to read it
+type FormClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewFormClient creates a client of the form service at baseURL
+func NewFormClient(baseURL string, timeout time.Duration) *FormClient {
+	return &FormClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+// CanViewForm reports whether the user the token was issued to is the owner
+// or a collaborator of the form
+func (c *FormClient) CanViewForm(ctx context.Context, token, formID string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/api/v1/forms/"+url.PathEscape(formID), nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to get form: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("form service returned status %d", resp.StatusCode)
+	}
+}
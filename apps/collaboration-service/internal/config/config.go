@@ -19,6 +19,7 @@ type Config struct {
 	Kafka     KafkaConfig     `mapstructure:"kafka"`
 	Logging   LoggingConfig   `mapstructure:"logging"`
 	Metrics   MetricsConfig   `mapstructure:"metrics"`
+	Dashboard DashboardConfig `mapstructure:"dashboard"`
 }
 
 // ServerConfig holds server configuration
@@ -77,6 +78,13 @@ type WebSocketConfig struct {
 	MaxUsersPerRoom   int           `mapstructure:"max_users_per_room"`
 	MessageRateLimit  int           `mapstructure:"message_rate_limit"`
 	RateLimitWindow   time.Duration `mapstructure:"rate_limit_window"`
+	// SessionGracePeriod is how long a client can resume its session after
+	// disconnecting, receiving the frames it missed; zero disables resuming
+	SessionGracePeriod time.Duration `mapstructure:"session_grace_period"`
+	// SessionBufferFrames and SessionBufferAge bound the frames kept for
+	// replay in each session
+	SessionBufferFrames int           `mapstructure:"session_buffer_frames"`
+	SessionBufferAge    time.Duration `mapstructure:"session_buffer_age"`
 }
 
 // KafkaConfig holds Kafka configuration
@@ -144,6 +152,19 @@ type MetricsConfig struct {
 	Namespace string `mapstructure:"namespace"`
 }
 
+// DashboardConfig holds the configuration of the live response counts pushed
+// to the owners watching the dashboard of a form
+type DashboardConfig struct {
+	// FormServiceURL authorizes the owners and collaborators of forms
+	FormServiceURL string `mapstructure:"form_service_url"`
+	// AnalyticsServiceURL serves the total responses of forms, sent when a
+	// dashboard is opened
+	AnalyticsServiceURL string        `mapstructure:"analytics_service_url"`
+	RequestTimeout      time.Duration `mapstructure:"request_timeout"`
+	// StatsInterval is the least time between two stats frames of a form
+	StatsInterval time.Duration `mapstructure:"stats_interval"`
+}
+
 // Load loads configuration from environment variables and config files
 func Load() (*Config, error) {
 	// Load .env file if it exists
@@ -229,6 +250,15 @@ func setDefaults() {
 	viper.SetDefault("websocket.max_users_per_room", 100)
 	viper.SetDefault("websocket.message_rate_limit", 60)
 	viper.SetDefault("websocket.rate_limit_window", "1m")
+	viper.SetDefault("websocket.session_grace_period", "60s")
+	viper.SetDefault("websocket.session_buffer_frames", 500)
+	viper.SetDefault("websocket.session_buffer_age", "60s")
+
+	// Dashboard defaults
+	viper.SetDefault("dashboard.form_service_url", "http://form-service:8080")
+	viper.SetDefault("dashboard.analytics_service_url", "http://analytics-service:8084")
+	viper.SetDefault("dashboard.request_timeout", "5s")
+	viper.SetDefault("dashboard.stats_interval", "1s")
 
 	// Kafka defaults
 	viper.SetDefault("kafka.brokers", []string{"localhost:9092"})
@@ -306,6 +336,14 @@ func overrideWithEnv(config *Config) {
 		config.Auth.ServiceSecret = serviceSecret
 	}
 
+	// Dashboard
+	if url := os.Getenv("FORM_SERVICE_URL"); url != "" {
+		config.Dashboard.FormServiceURL = url
+	}
+	if url := os.Getenv("ANALYTICS_SERVICE_URL"); url != "" {
+		config.Dashboard.AnalyticsServiceURL = url
+	}
+
 	// Kafka
 	if brokers := os.Getenv("KAFKA_BROKERS"); brokers != "" {
 		// Simple parsing - in production you might want more sophisticated parsing
@@ -358,6 +396,19 @@ func validate(config *Config) error {
 		return fmt.Errorf("websocket pong_wait must be positive")
 	}
 
+	if config.WebSocket.SessionGracePeriod < 0 || config.WebSocket.SessionBufferFrames < 0 || config.WebSocket.SessionBufferAge < 0 {
+		return fmt.Errorf("websocket session grace period and buffer bounds must not be negative")
+	}
+
+	// Validate dashboards
+	if config.Dashboard.FormServiceURL == "" || config.Dashboard.AnalyticsServiceURL == "" {
+		return fmt.Errorf("dashboard form and analytics service URLs are required")
+	}
+
+	if config.Dashboard.StatsInterval <= 0 {
+		return fmt.Errorf("dashboard stats_interval must be positive")
+	}
+
 	return nil
 }
 
@@ -28,6 +28,8 @@ type MetricsResponse struct {
 	ActiveRooms       int64   `json:"activeRooms" example:"8"`
 	MessagesPerSecond int64   `json:"messagesPerSecond" example:"45"`
 	ErrorsPerSecond   int64   `json:"errorsPerSecond" example:"0"`
+	ResumedSessions   int64   `json:"resumedSessions" example:"12"`
+	ResyncedSessions  int64   `json:"resyncedSessions" example:"1"`
 	AverageLatency    float64 `json:"averageLatency" example:"12.5"`
 	MemoryUsage       string  `json:"memoryUsage" example:"256MB"`
 	CPUUsage          float64 `json:"cpuUsage" example:"15.2"`
@@ -172,6 +174,8 @@ func getMetrics(hub *websocket.Hub) http.HandlerFunc {
 			ActiveRooms:       metrics.ActiveRooms,
 			MessagesPerSecond: metrics.MessagesPerSecond,
 			ErrorsPerSecond:   metrics.ErrorsPerSecond,
+			ResumedSessions:   metrics.ResumedSessions,
+			ResyncedSessions:  metrics.ResyncedSessions,
 			AverageLatency:    12.5,    // Mock data
 			MemoryUsage:       "256MB", // Mock data
 			CPUUsage:          15.2,    // Mock data
@@ -19,6 +19,11 @@ const (
 	EventJoinFormResponse  EventType = "join:form:response"
 	EventLeaveFormResponse EventType = "leave:form:response"
 
+	// Dashboard events
+	EventJoinDashboard  EventType = "join:dashboard"
+	EventLeaveDashboard EventType = "leave:dashboard"
+	EventStats          EventType = "stats"
+
 	// Cursor events
 	EventCursorUpdate EventType = "cursor:update"
 
@@ -31,6 +36,12 @@ const (
 	EventFormUpdate EventType = "form:update"
 	EventFormDelete EventType = "form:delete"
 
+	// Activity events, the changes recorded in the activity feed of a form
+	EventActivity EventType = "activity"
+
+	// Patch events, the changes merged into a form by its builders
+	EventFormPatch EventType = "form:patch"
+
 	// System events
 	EventError      EventType = "error"
 	EventHeartbeat  EventType = "heartbeat"
@@ -38,6 +49,11 @@ const (
 	EventRateLimit  EventType = "rate:limit"
 	EventPing       EventType = "ping"
 	EventPong       EventType = "pong"
+
+	// Session events, telling a client the session it can resume after a
+	// network blip, or that it has to reload its state
+	EventSession        EventType = "session"
+	EventResyncRequired EventType = "resync_required"
 )
 
 // Message represents a WebSocket message
@@ -48,6 +64,12 @@ type Message struct {
 	MessageID string      `json:"messageId"`
 	UserID    string      `json:"userId,omitempty"`
 	FormID    string      `json:"formId,omitempty"`
+	// Seq numbers the frames sent in a session, for the client to tell the
+	// last one it saw when resuming the session
+	Seq uint64 `json:"seq,omitempty"`
+	// ClientMsgID is given by clients to the messages they send, so a
+	// message sent again after a reconnect is handled once
+	ClientMsgID string `json:"clientMsgId,omitempty"`
 }
 
 // NewMessage creates a new message with auto-generated ID and timestamp
@@ -233,6 +255,19 @@ type ErrorPayload struct {
 	Details string `json:"details,omitempty"`
 }
 
+// SessionPayload represents the payload for session events
+type SessionPayload struct {
+	SessionToken string `json:"sessionToken"`
+	Resumed      bool   `json:"resumed"`
+	// LastSeq is the sequence number of the last frame sent in the session
+	LastSeq uint64 `json:"lastSeq"`
+}
+
+// ResyncRequiredPayload represents the payload for resync_required events
+type ResyncRequiredPayload struct {
+	Reason string `json:"reason"`
+}
+
 // JoinFormResponsePayload represents the response payload for join:form event
 type JoinFormResponsePayload struct {
 	FormID    string    `json:"formId"`
@@ -250,6 +285,59 @@ type LeaveFormResponsePayload struct {
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// JoinDashboardPayload represents the payload for join:dashboard event
+type JoinDashboardPayload struct {
+	FormID string `json:"formId" validate:"required"`
+}
+
+// LeaveDashboardPayload represents the payload for leave:dashboard event
+type LeaveDashboardPayload struct {
+	FormID string `json:"formId"`
+}
+
+// StatsPayload represents the payload for stats events, the live response
+// counts of a form
+type StatsPayload struct {
+	FormID string `json:"formId"`
+	// Total is the number of responses to the form
+	Total int64 `json:"total"`
+	// LastMinute is the number of responses received in the last minute
+	LastMinute int64 `json:"lastMinute"`
+}
+
+// ActivityPayload represents the payload for activity events, a change to
+// the definition of a form recorded by the form service. Changes names the
+// fields an update changed, never their values.
+type ActivityPayload struct {
+	ID         string    `json:"id"`
+	FormID     string    `json:"formId"`
+	ActorID    string    `json:"actorId"`
+	Action     string    `json:"action"`
+	TargetType string    `json:"targetType"`
+	TargetID   string    `json:"targetId"`
+	Changes    []string  `json:"changes,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// FormPatchPayload represents the payload for form patch events, the
+// changes a builder merged into a form, taking it from PreviousVersion to
+// Version. Builders at PreviousVersion apply them; others read the form.
+type FormPatchPayload struct {
+	FormID          string           `json:"formId"`
+	ActorID         string           `json:"actorId"`
+	PreviousVersion int              `json:"previousVersion"`
+	Version         int              `json:"version"`
+	Operations      []PatchOperation `json:"operations"`
+	CreatedAt       time.Time        `json:"createdAt"`
+}
+
+// PatchOperation is a change of a form patch, in the style of JSON Patch
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
 // PongPayload represents the payload for pong events
 type PongPayload struct {
 	Timestamp time.Time `json:"timestamp"`
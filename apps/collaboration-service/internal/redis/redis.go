@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -282,6 +283,90 @@ func (s *Service) SubscribeToChannel(ctx context.Context, channels ...string) *r
 	return s.client.Subscribe(ctx, channels...)
 }
 
+// FormStatsChannel returns the channel the event bus publishes the response
+// counts of a form to. It is shared with the event bus and not prefixed.
+func FormStatsChannel(formID string) string {
+	return "form:" + formID + ":stats"
+}
+
+// SubscribeFormStats subscribes to the response counts of a form. Messages
+// are delivered on the returned channel until unsubscribe is called or ctx
+// is done.
+func (s *Service) SubscribeFormStats(ctx context.Context, formID string) (<-chan []byte, func() error, error) {
+	pubsub := s.client.Subscribe(ctx, FormStatsChannel(formID))
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, nil, fmt.Errorf("failed to subscribe to form stats: %w", err)
+	}
+
+	messages := make(chan []byte)
+	go func() {
+		defer close(messages)
+		for message := range pubsub.Channel() {
+			select {
+			case messages <- []byte(message.Payload):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return messages, pubsub.Close, nil
+}
+
+// FormActivityPattern matches the channels the form service publishes the
+// activity of forms to, form:<id>:activity. They are shared with the form
+// service and not prefixed.
+const FormActivityPattern = "form:*:activity"
+
+// SubscribeFormActivity subscribes to the activity of every form. Messages
+// are delivered on the returned channel until unsubscribe is called or ctx
+// is done.
+func (s *Service) SubscribeFormActivity(ctx context.Context) (<-chan []byte, func() error, error) {
+	messages, unsubscribe, err := s.psubscribe(ctx, FormActivityPattern)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to subscribe to form activity: %w", err)
+	}
+	return messages, unsubscribe, nil
+}
+
+// FormPatchesPattern matches the channels the form service publishes the
+// patches merged into forms to, form:<id>:patches. They are shared with the
+// form service and not prefixed.
+const FormPatchesPattern = "form:*:patches"
+
+// SubscribeFormPatches subscribes to the patches merged into every form, as
+// SubscribeFormActivity does to their activity
+func (s *Service) SubscribeFormPatches(ctx context.Context) (<-chan []byte, func() error, error) {
+	messages, unsubscribe, err := s.psubscribe(ctx, FormPatchesPattern)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to subscribe to form patches: %w", err)
+	}
+	return messages, unsubscribe, nil
+}
+
+// psubscribe subscribes to the channels matching pattern, delivering their
+// payloads until unsubscribe is called or ctx is done
+func (s *Service) psubscribe(ctx context.Context, pattern string) (<-chan []byte, func() error, error) {
+	pubsub := s.client.PSubscribe(ctx, pattern)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, nil, err
+	}
+
+	messages := make(chan []byte)
+	go func() {
+		defer close(messages)
+		for message := range pubsub.Channel() {
+			select {
+			case messages <- []byte(message.Payload):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return messages, pubsub.Close, nil
+}
+
 // PublishToRoom publishes a message to all users in a room
 func (s *Service) PublishToRoom(ctx context.Context, formID string, message *models.Message) error {
 	channel := s.getRoomChannelKey(formID)
@@ -545,3 +630,130 @@ func (s *Service) getCursorPositionKey(formID, userID string) string {
 func (s *Service) getQuestionUpdateKey(formID, questionID, userID string) string {
 	return fmt.Sprintf("%s:question_update:%s:%s:%s:%d", s.keyPrefix, formID, questionID, userID, time.Now().Unix())
 }
+
+// Privacy
+
+// EraseUser removes the presence, cursors, sessions and question update
+// history of a user, returning how many of each were removed. Erasing a user
+// twice removes nothing the second time.
+func (s *Service) EraseUser(ctx context.Context, userID string) (map[string]int64, error) {
+	counts := map[string]int64{
+		"sessions_removed":         0,
+		"presence_removed":         0,
+		"cursors_removed":          0,
+		"question_updates_removed": 0,
+		"rooms_updated":            0,
+	}
+
+	sessionKeys, err := s.scanKeys(ctx, s.getUserFormSessionKey(userID, "*"))
+	if err != nil {
+		return nil, err
+	}
+	sessionKeys = append(sessionKeys, s.getUserSessionKey(userID), s.getRateLimitKey(userID))
+	removed, err := s.client.Del(ctx, sessionKeys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove user sessions: %w", err)
+	}
+	counts["sessions_removed"] = removed
+
+	cursorKeys, err := s.scanKeys(ctx, s.getCursorKey("*", userID))
+	if err != nil {
+		return nil, err
+	}
+	positionKeys, err := s.scanKeys(ctx, s.getCursorPositionKey("*", userID))
+	if err != nil {
+		return nil, err
+	}
+	if keys := append(cursorKeys, positionKeys...); len(keys) > 0 {
+		if counts["cursors_removed"], err = s.client.Del(ctx, keys...).Result(); err != nil {
+			return nil, fmt.Errorf("failed to remove user cursors: %w", err)
+		}
+	}
+
+	updateKeys, err := s.scanKeys(ctx, fmt.Sprintf("%s:question_update:*:*:%s:*", s.keyPrefix, userID))
+	if err != nil {
+		return nil, err
+	}
+	if len(updateKeys) > 0 {
+		if counts["question_updates_removed"], err = s.client.Del(ctx, updateKeys...).Result(); err != nil {
+			return nil, fmt.Errorf("failed to remove question updates: %w", err)
+		}
+	}
+
+	memberKeys, err := s.scanKeys(ctx, s.getRoomUsersKey("*"))
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range memberKeys {
+		n, err := s.client.SRem(ctx, key, userID).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to remove user from room: %w", err)
+		}
+		counts["presence_removed"] += n
+	}
+
+	roomKeys, err := s.scanKeys(ctx, s.getRoomKey("*"))
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range roomKeys {
+		if strings.HasSuffix(key, ":users") {
+			continue
+		}
+		updated, err := s.removeFromRoom(ctx, key, userID)
+		if err != nil {
+			return nil, err
+		}
+		if updated {
+			counts["rooms_updated"]++
+		}
+	}
+
+	return counts, nil
+}
+
+// removeFromRoom drops the user and their cursor from the room stored at key,
+// keeping its expiry. It reports whether the room held either.
+func (s *Service) removeFromRoom(ctx context.Context, key, userID string) (bool, error) {
+	data, err := s.client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get room: %w", err)
+	}
+
+	var room models.Room
+	if err := json.Unmarshal([]byte(data), &room); err != nil {
+		return false, nil // Skip invalid data
+	}
+	_, present := room.Users[userID]
+	_, cursor := room.Cursors[userID]
+	if !present && !cursor {
+		return false, nil
+	}
+	delete(room.Users, userID)
+	delete(room.Cursors, userID)
+
+	updated, err := json.Marshal(&room)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal room: %w", err)
+	}
+	if err := s.client.Set(ctx, key, updated, redis.KeepTTL).Err(); err != nil {
+		return false, fmt.Errorf("failed to save room: %w", err)
+	}
+	return true, nil
+}
+
+// scanKeys returns the keys matching pattern without blocking Redis
+func (s *Service) scanKeys(ctx context.Context, pattern string) ([]string, error) {
+	var keys []string
+	iter := s.client.Scan(ctx, 0, pattern, 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", pattern, err)
+	}
+	return keys, nil
+}
@@ -0,0 +1,91 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/kamkaiz/x-form-backend/collaboration-service/internal/models"
+	"go.uber.org/zap"
+)
+
+// ActivitySubscriber subscribes to the activity the form service publishes
+// for every form
+type ActivitySubscriber interface {
+	SubscribeFormActivity(ctx context.Context) (<-chan []byte, func() error, error)
+}
+
+// formActivity is an activity published by the form service
+type formActivity struct {
+	ID         string    `json:"id"`
+	FormID     string    `json:"form_id"`
+	ActorID    string    `json:"actor_id"`
+	Action     string    `json:"action"`
+	TargetType string    `json:"target_type"`
+	TargetID   string    `json:"target_id"`
+	Changes    []string  `json:"changes"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ForwardActivity pushes the activity of forms to the clients in their rooms
+// as activity frames until ctx is done. Every replica subscribes to every
+// form and sends to the rooms it holds; activity of forms without a room
+// here is dropped.
+func (h *Hub) ForwardActivity(ctx context.Context, subscriber ActivitySubscriber) error {
+	activities, unsubscribe, err := subscriber.SubscribeFormActivity(ctx)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		defer func() {
+			if err := unsubscribe(); err != nil {
+				h.logger.Warn("Failed to unsubscribe from form activity", zap.Error(err))
+			}
+		}()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case data, ok := <-activities:
+				if !ok {
+					return
+				}
+				message, ok := h.activityFrame(data)
+				if !ok {
+					continue
+				}
+				select {
+				case h.broadcast <- message:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// activityFrame returns the activity frame of an activity published by the
+// form service, addressed to the room of its form
+func (h *Hub) activityFrame(data []byte) (*models.Message, bool) {
+	var activity formActivity
+	if err := json.Unmarshal(data, &activity); err != nil || activity.FormID == "" || activity.Action == "" {
+		h.logger.Warn("Dropping invalid form activity")
+		return nil, false
+	}
+
+	message := models.NewMessage(models.EventActivity, &models.ActivityPayload{
+		ID:         activity.ID,
+		FormID:     activity.FormID,
+		ActorID:    activity.ActorID,
+		Action:     activity.Action,
+		TargetType: activity.TargetType,
+		TargetID:   activity.TargetID,
+		Changes:    activity.Changes,
+		CreatedAt:  activity.CreatedAt,
+	})
+	message.FormID = activity.FormID
+	message.UserID = activity.ActorID
+	return message, true
+}
@@ -0,0 +1,80 @@
+package websocket
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kamkaiz/x-form-backend/collaboration-service/internal/config"
+	"github.com/kamkaiz/x-form-backend/collaboration-service/internal/models"
+	"go.uber.org/zap"
+)
+
+// activityChannel delivers the activities sent on it
+type activityChannel chan []byte
+
+func (c activityChannel) SubscribeFormActivity(context.Context) (<-chan []byte, func() error, error) {
+	return c, func() error { return nil }, nil
+}
+
+// joinedClient returns a client of the user in the room of the form
+func joinedClient(h *Hub, formID, userID string) *Client {
+	client := &Client{ID: userID, UserID: userID, FormID: formID, send: make(chan *models.Message, 256)}
+	room, ok := h.rooms[formID]
+	if !ok {
+		room = models.NewRoom(formID, 10)
+		h.rooms[formID] = room
+	}
+	room.AddUser(&models.User{ID: userID})
+	h.clients[client] = true
+	h.userConnections[userID] = append(h.userConnections[userID], client)
+	return client
+}
+
+func TestForwardActivity(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hub := NewHub(nil, nil, &config.WebSocketConfig{}, zap.NewNop())
+	builder := joinedClient(hub, "form-1", "editor")
+	elsewhere := joinedClient(hub, "form-2", "viewer")
+	go hub.Run(ctx)
+
+	activities := make(activityChannel)
+	if err := hub.ForwardActivity(ctx, activities); err != nil {
+		t.Fatal(err)
+	}
+	activities <- []byte("not json")
+	activities <- []byte(`{"id":"a-1","form_id":"form-1","actor_id":"owner","action":"question.updated",` +
+		`"target_type":"question","target_id":"q-1","changes":["title","options"],"created_at":"2026-03-01T12:00:00Z"}`)
+
+	select {
+	case message := <-builder.send:
+		payload, ok := message.Payload.(*models.ActivityPayload)
+		if message.Type != models.EventActivity || !ok {
+			t.Fatalf("got %s frame %+v, want activity", message.Type, message.Payload)
+		}
+		want := &models.ActivityPayload{
+			ID:         "a-1",
+			FormID:     "form-1",
+			ActorID:    "owner",
+			Action:     "question.updated",
+			TargetType: "question",
+			TargetID:   "q-1",
+			Changes:    []string{"title", "options"},
+			CreatedAt:  time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		}
+		if !reflect.DeepEqual(payload, want) {
+			t.Errorf("activity = %+v, want %+v", payload, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no activity frame sent to the room of the form")
+	}
+
+	select {
+	case message := <-elsewhere.send:
+		t.Errorf("client of another form got %s frame", message.Type)
+	default:
+	}
+}
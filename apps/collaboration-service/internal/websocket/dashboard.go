@@ -0,0 +1,294 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kamkaiz/x-form-backend/collaboration-service/internal/models"
+	"go.uber.org/zap"
+)
+
+// ErrDashboardAccessDenied is returned to users joining the dashboard of a
+// form they neither own nor collaborate on
+var ErrDashboardAccessDenied = errors.New("access denied to form dashboard")
+
+// lastMinuteSeconds is the number of seconds the last minute count spans
+const lastMinuteSeconds = 60
+
+// DashboardRoom returns the room of the owners watching the dashboard of a
+// form
+func DashboardRoom(formID string) string {
+	return "form:" + formID + ":dashboard"
+}
+
+// FormAccess checks whether users may watch the dashboard of a form
+type FormAccess interface {
+	// CanViewForm reports whether the user the token was issued to is the
+	// owner or a collaborator of the form
+	CanViewForm(ctx context.Context, token, formID string) (bool, error)
+}
+
+// ResponseTotals reads the number of responses to forms
+type ResponseTotals interface {
+	TotalResponses(ctx context.Context, token, formID string) (int64, error)
+}
+
+// StatsSubscriber subscribes to the response counts the event bus publishes
+// for a form
+type StatsSubscriber interface {
+	SubscribeFormStats(ctx context.Context, formID string) (<-chan []byte, func() error, error)
+}
+
+// statsUpdate is a response count published by the event bus: the number of
+// responses to the form since its previous update
+type statsUpdate struct {
+	Type   string `json:"type"`
+	FormID string `json:"form_id"`
+	Count  int64  `json:"count"`
+}
+
+// Dashboards pushes the live response counts of forms to the clients
+// watching their dashboards. A form is subscribed to while at least one
+// client watches it. Updates are coalesced: each form gets at most one stats
+// frame per interval, sent once its counts changed.
+type Dashboards struct {
+	access     FormAccess
+	totals     ResponseTotals
+	subscriber StatsSubscriber
+	interval   time.Duration
+	logger     *zap.Logger
+	now        func() time.Time
+	newTicker  func(d time.Duration) (<-chan time.Time, func())
+
+	mu    sync.Mutex
+	feeds map[string]*dashboardFeed
+}
+
+// dashboardFeed holds the counts of a form watched by clients
+type dashboardFeed struct {
+	formID   string
+	watchers map[*Client]bool
+	total    int64
+	// seconds and counts are a ring of the responses received in each of the
+	// last seconds
+	seconds [lastMinuteSeconds]int64
+	counts  [lastMinuteSeconds]int64
+	// changed reports whether the total changed since the last frame
+	changed        bool
+	sentLastMinute int64
+	stop           func()
+}
+
+// NewDashboards creates the dashboards of forms, authorized by access,
+// starting from the totals read from totals and updated from subscriber
+func NewDashboards(access FormAccess, totals ResponseTotals, subscriber StatsSubscriber, interval time.Duration, logger *zap.Logger) *Dashboards {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Dashboards{
+		access:     access,
+		totals:     totals,
+		subscriber: subscriber,
+		interval:   interval,
+		logger:     logger,
+		now:        time.Now,
+		newTicker: func(d time.Duration) (<-chan time.Time, func()) {
+			ticker := time.NewTicker(d)
+			return ticker.C, ticker.Stop
+		},
+		feeds: make(map[string]*dashboardFeed),
+	}
+}
+
+// Join makes the client watch the dashboard of a form, leaving the dashboard
+// it watched before. The client is sent the current counts right away; the
+// first client of a form reads its total from the analytics service.
+func (d *Dashboards) Join(ctx context.Context, client *Client, formID string) error {
+	allowed, err := d.access.CanViewForm(ctx, client.token, formID)
+	if err != nil {
+		return fmt.Errorf("failed to authorize dashboard: %w", err)
+	}
+	if !allowed {
+		return ErrDashboardAccessDenied
+	}
+
+	// The total is read without holding the lock, and the form looked up
+	// again since the last watcher may have left meanwhile
+	var total int64
+	fetched := false
+	for {
+		d.mu.Lock()
+		if _, ok := d.feeds[formID]; ok || fetched {
+			break
+		}
+		d.mu.Unlock()
+
+		if total, err = d.totals.TotalResponses(ctx, client.token, formID); err != nil {
+			return fmt.Errorf("failed to get response total: %w", err)
+		}
+		fetched = true
+	}
+	defer d.mu.Unlock()
+
+	if client.Dashboard != formID {
+		d.leave(client)
+	}
+	feed, ok := d.feeds[formID]
+	if !ok {
+		if feed, err = d.open(formID, total); err != nil {
+			return err
+		}
+	}
+	feed.watchers[client] = true
+	client.Dashboard = formID
+
+	d.send(client, d.frame(feed, feed.lastMinute(d.now())))
+	return nil
+}
+
+// Leave stops the client watching its dashboard, unsubscribing from the form
+// once nobody watches it
+func (d *Dashboards) Leave(client *Client) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.leave(client)
+}
+
+func (d *Dashboards) leave(client *Client) {
+	feed, ok := d.feeds[client.Dashboard]
+	client.Dashboard = ""
+	if !ok {
+		return
+	}
+
+	delete(feed.watchers, client)
+	if len(feed.watchers) == 0 {
+		feed.stop()
+		delete(d.feeds, feed.formID)
+	}
+}
+
+// open subscribes to the counts of a form
+func (d *Dashboards) open(formID string, total int64) (*dashboardFeed, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	updates, unsubscribe, err := d.subscriber.SubscribeFormStats(ctx, formID)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	ticks, stopTicker := d.newTicker(d.interval)
+
+	feed := &dashboardFeed{
+		formID:   formID,
+		watchers: make(map[*Client]bool),
+		total:    total,
+		stop: func() {
+			cancel()
+			stopTicker()
+			if err := unsubscribe(); err != nil {
+				d.logger.Warn("Failed to unsubscribe from form stats", zap.String("formID", formID), zap.Error(err))
+			}
+		},
+	}
+	d.feeds[formID] = feed
+
+	go d.run(ctx, feed, updates, ticks)
+	return feed, nil
+}
+
+// run counts the updates of a feed and flushes them every tick until ctx is
+// done
+func (d *Dashboards) run(ctx context.Context, feed *dashboardFeed, updates <-chan []byte, ticks <-chan time.Time) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case data, ok := <-updates:
+			if !ok {
+				return
+			}
+			var update statsUpdate
+			if err := json.Unmarshal(data, &update); err != nil || update.Count <= 0 {
+				d.logger.Warn("Dropping invalid form stats update", zap.String("formID", feed.formID))
+				continue
+			}
+			d.mu.Lock()
+			feed.add(update.Count, d.now())
+			d.mu.Unlock()
+
+		case <-ticks:
+			d.flush(feed)
+		}
+	}
+}
+
+// flush sends the counts of a feed to its watchers when they changed since
+// the last frame: new responses, or older ones leaving the last minute
+func (d *Dashboards) flush(feed *dashboardFeed) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	lastMinute := feed.lastMinute(d.now())
+	if !feed.changed && lastMinute == feed.sentLastMinute {
+		return
+	}
+	feed.changed = false
+	feed.sentLastMinute = lastMinute
+	frame := d.frame(feed, lastMinute)
+	for client := range feed.watchers {
+		d.send(client, frame)
+	}
+}
+
+// frame returns the stats frame of a feed
+func (d *Dashboards) frame(feed *dashboardFeed, lastMinute int64) *models.Message {
+	message := models.NewMessage(models.EventStats, &models.StatsPayload{
+		FormID:     feed.formID,
+		Total:      feed.total,
+		LastMinute: lastMinute,
+	})
+	message.FormID = feed.formID
+	return message
+}
+
+// send sends a frame to a client, dropping it when the client is too slow:
+// the next frame carries the counts anyway
+func (d *Dashboards) send(client *Client, message *models.Message) {
+	select {
+	case client.send <- message:
+	default:
+		d.logger.Warn("Dropping stats frame of a slow client", zap.String("clientID", client.ID))
+	}
+}
+
+// add counts n responses received at now
+func (f *dashboardFeed) add(n int64, now time.Time) {
+	second := now.Unix()
+	i := second % lastMinuteSeconds
+	if f.seconds[i] != second {
+		f.seconds[i] = second
+		f.counts[i] = 0
+	}
+	f.counts[i] += n
+	f.total += n
+	f.changed = true
+}
+
+// lastMinute returns the number of responses received in the minute up to
+// now
+func (f *dashboardFeed) lastMinute(now time.Time) int64 {
+	var count int64
+	second := now.Unix()
+	for i, s := range f.seconds {
+		if s > second-lastMinuteSeconds && s <= second {
+			count += f.counts[i]
+		}
+	}
+	return count
+}
@@ -0,0 +1,176 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kamkaiz/x-form-backend/collaboration-service/internal/models"
+)
+
+// ownerAccess allows the owner token to watch any dashboard
+type ownerAccess struct{}
+
+func (ownerAccess) CanViewForm(_ context.Context, token, _ string) (bool, error) {
+	return token == "owner", nil
+}
+
+// fixedTotals returns the same total for every form
+type fixedTotals int64
+
+func (t fixedTotals) TotalResponses(context.Context, string, string) (int64, error) {
+	return int64(t), nil
+}
+
+// channelSubscriber delivers the updates sent on its channel
+type channelSubscriber struct {
+	mu           sync.Mutex
+	updates      chan []byte
+	subscribed   int
+	unsubscribed int
+}
+
+func (s *channelSubscriber) SubscribeFormStats(context.Context, string) (<-chan []byte, func() error, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribed++
+	return s.updates, func() error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.unsubscribed++
+		return nil
+	}, nil
+}
+
+func newDashboardClient(id, token string) *Client {
+	return &Client{ID: id, UserID: id, token: token, send: make(chan *models.Message, 256)}
+}
+
+// receiveStats returns the next stats frame sent to the client
+func receiveStats(t *testing.T, client *Client) *models.StatsPayload {
+	t.Helper()
+	select {
+	case message := <-client.send:
+		payload, ok := message.Payload.(*models.StatsPayload)
+		if message.Type != models.EventStats || !ok {
+			t.Fatalf("got %s frame %+v, want stats", message.Type, message.Payload)
+		}
+		return payload
+	case <-time.After(time.Second):
+		t.Fatal("no stats frame sent")
+		return nil
+	}
+}
+
+func TestDashboardCoalescesResponseCounts(t *testing.T) {
+	ctx := context.Background()
+	subscriber := &channelSubscriber{updates: make(chan []byte)}
+	ticks := make(chan time.Time)
+	clock := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	var clockMu sync.Mutex
+	now := func() time.Time {
+		clockMu.Lock()
+		defer clockMu.Unlock()
+		return clock
+	}
+	advance := func(d time.Duration) {
+		clockMu.Lock()
+		defer clockMu.Unlock()
+		clock = clock.Add(d)
+	}
+
+	d := NewDashboards(ownerAccess{}, fixedTotals(42), subscriber, time.Second, nil)
+	d.now = now
+	d.newTicker = func(time.Duration) (<-chan time.Time, func()) { return ticks, func() {} }
+
+	if err := d.Join(ctx, newDashboardClient("stranger", "stranger"), "form-1"); !errors.Is(err, ErrDashboardAccessDenied) {
+		t.Fatalf("stranger joined: err = %v, want ErrDashboardAccessDenied", err)
+	}
+
+	owner := newDashboardClient("owner", "owner")
+	if err := d.Join(ctx, owner, "form-1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := receiveStats(t, owner); got.Total != 42 || got.LastMinute != 0 {
+		t.Fatalf("first frame = %+v, want the total of the analytics service", got)
+	}
+
+	// 100 responses over 5 seconds, published one at a time, are pushed as
+	// one frame a second. The updates are sent on an unbuffered channel, so
+	// each is counted before the next tick is received.
+	for second := 0; second < 5; second++ {
+		for i := 0; i < 20; i++ {
+			subscriber.updates <- []byte(`{"type":"response_count","form_id":"form-1","count":1}`)
+		}
+		advance(time.Second)
+		ticks <- clock
+	}
+	// A tick without new responses pushes nothing; once received, the
+	// previous tick is flushed
+	ticks <- clock
+
+	var frames []*models.StatsPayload
+	for len(owner.send) > 0 {
+		frames = append(frames, receiveStats(t, owner))
+	}
+	if len(frames) != 5 {
+		t.Fatalf("got %d frames for 100 responses, want 5", len(frames))
+	}
+	for i, frame := range frames {
+		if want := int64(42 + 20*(i+1)); frame.Total != want || frame.LastMinute != int64(20*(i+1)) {
+			t.Errorf("frame %d = %+v, want total %d", i, frame, want)
+		}
+	}
+
+	// A second owner starts from the current counts, and both are told once
+	// the responses leave the last minute
+	other := newDashboardClient("collaborator", "owner")
+	if err := d.Join(ctx, other, "form-1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := receiveStats(t, other); got.Total != 142 || got.LastMinute != 100 {
+		t.Errorf("frame on join = %+v, want total 142", got)
+	}
+	advance(time.Minute)
+	ticks <- clock
+	ticks <- clock
+	for _, client := range []*Client{owner, other} {
+		if got := receiveStats(t, client); got.Total != 142 || got.LastMinute != 0 {
+			t.Errorf("frame of %s = %+v, want no response in the last minute", client.ID, got)
+		}
+	}
+
+	// The form is unsubscribed once nobody watches it
+	d.Leave(owner)
+	d.Leave(other)
+	subscriber.mu.Lock()
+	defer subscriber.mu.Unlock()
+	if subscriber.subscribed != 1 || subscriber.unsubscribed != 1 {
+		t.Errorf("subscribed %d and unsubscribed %d times, want once each", subscriber.subscribed, subscriber.unsubscribed)
+	}
+	if len(d.feeds) != 0 {
+		t.Errorf("feeds = %v, want none", d.feeds)
+	}
+}
+
+func TestDashboardTotalsOnlyReadForNewFeeds(t *testing.T) {
+	d := NewDashboards(ownerAccess{}, fixedTotals(7), &channelSubscriber{updates: make(chan []byte)}, time.Second, nil)
+	d.newTicker = func(time.Duration) (<-chan time.Time, func()) { return nil, func() {} }
+	client := newDashboardClient("owner", "owner")
+
+	for _, formID := range []string{"form-1", "form-1", "form-2"} {
+		if err := d.Join(context.Background(), client, formID); err != nil {
+			t.Fatal(err)
+		}
+		if got := receiveStats(t, client); got.FormID != formID || got.Total != 7 {
+			t.Errorf("frame = %+v, want the total of %s", got, formID)
+		}
+	}
+	if _, ok := d.feeds["form-1"]; ok || client.Dashboard != "form-2" {
+		t.Errorf("watching %q with feeds %v, want only form-2", client.Dashboard, fmt.Sprint(d.feeds))
+	}
+	d.Leave(client)
+}
@@ -3,6 +3,7 @@ package websocket
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -33,6 +34,8 @@ func (h *Hub) registerEventHandlers() {
 	h.eventHandlers[models.EventQuestionCreate] = &QuestionCreateHandler{hub: h}
 	h.eventHandlers[models.EventQuestionDelete] = &QuestionDeleteHandler{hub: h}
 	h.eventHandlers[models.EventPing] = &PingHandler{hub: h}
+	h.eventHandlers[models.EventJoinDashboard] = &JoinDashboardHandler{hub: h}
+	h.eventHandlers[models.EventLeaveDashboard] = &LeaveDashboardHandler{hub: h}
 }
 
 // JoinFormHandler handles join form events
@@ -79,9 +82,7 @@ func (h *JoinFormHandler) Handle(ctx context.Context, client *Client, message *m
 	})
 
 	// Send response to client
-	select {
-	case client.send <- response:
-	default:
+	if !client.deliver(response) {
 		return fmt.Errorf("failed to send join response")
 	}
 
@@ -117,6 +118,52 @@ func (h *JoinFormHandler) Handle(ctx context.Context, client *Client, message *m
 	return nil
 }
 
+// JoinDashboardHandler handles join dashboard events. Only the owners and
+// collaborators of a form may watch its dashboard.
+type JoinDashboardHandler struct {
+	hub *Hub
+}
+
+func (h *JoinDashboardHandler) Handle(ctx context.Context, client *Client, message *models.Message) error {
+	if h.hub.dashboards == nil {
+		return fmt.Errorf("form dashboards are not enabled")
+	}
+
+	var payload models.JoinDashboardPayload
+	if err := convertPayload(message.Payload, &payload); err != nil {
+		return fmt.Errorf("invalid join dashboard payload: %w", err)
+	}
+	if payload.FormID == "" {
+		return fmt.Errorf("form ID is required")
+	}
+
+	if err := h.hub.dashboards.Join(ctx, client, payload.FormID); err != nil {
+		if errors.Is(err, ErrDashboardAccessDenied) {
+			client.sendError("ACCESS_DENIED", "Only the owners and collaborators of a form can watch its dashboard")
+			return nil
+		}
+		return fmt.Errorf("failed to join dashboard: %w", err)
+	}
+
+	h.hub.logger.Info("User joined dashboard",
+		zap.String("userID", client.UserID),
+		zap.String("room", DashboardRoom(payload.FormID)))
+
+	return nil
+}
+
+// LeaveDashboardHandler handles leave dashboard events
+type LeaveDashboardHandler struct {
+	hub *Hub
+}
+
+func (h *LeaveDashboardHandler) Handle(ctx context.Context, client *Client, message *models.Message) error {
+	if h.hub.dashboards != nil {
+		h.hub.dashboards.Leave(client)
+	}
+	return nil
+}
+
 // LeaveFormHandler handles leave form events
 type LeaveFormHandler struct {
 	hub *Hub
@@ -159,9 +206,7 @@ func (h *LeaveFormHandler) Handle(ctx context.Context, client *Client, message *
 	})
 
 	// Send response to client
-	select {
-	case client.send <- response:
-	default:
+	if !client.deliver(response) {
 		return fmt.Errorf("failed to send leave response")
 	}
 
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"sync"
 	"time"
 
@@ -60,6 +62,12 @@ type Hub struct {
 
 	// Event handlers
 	eventHandlers map[models.EventType]EventHandler
+
+	// Live response counts of form dashboards, nil when disabled
+	dashboards *Dashboards
+
+	// Sessions resumed by clients reconnecting after a network blip
+	sessions *Sessions
 }
 
 // Client represents a WebSocket client
@@ -79,6 +87,13 @@ type Client struct {
 	User   *models.User
 	FormID string
 
+	// Dashboard is the ID of the form whose dashboard the client watches
+	Dashboard string
+
+	// token is the token the client authenticated with, forwarded to the
+	// services checked on behalf of the user
+	token string
+
 	// Connection info
 	ConnectedAt time.Time
 	LastPing    time.Time
@@ -89,6 +104,10 @@ type Client struct {
 	// Rate limiting
 	rateLimitInfo *models.RateLimitInfo
 
+	// session numbers the frames sent to the client and keeps them for
+	// replay once it reconnects
+	session *session
+
 	// Context for cancellation
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -107,7 +126,11 @@ type Metrics struct {
 	ActiveRooms       int64
 	MessagesPerSecond int64
 	ErrorsPerSecond   int64
-	mu                sync.RWMutex
+	// Sessions resumed by reconnecting clients, and those the clients had
+	// to reload their state for
+	ResumedSessions  int64
+	ResyncedSessions int64
+	mu               sync.RWMutex
 }
 
 // RateLimiter handles rate limiting for WebSocket connections
@@ -132,6 +155,7 @@ func NewHub(redis *redisService.Service, authService *auth.Service, cfg *config.
 		metrics:         &Metrics{},
 		rateLimiter:     NewRateLimiter(redis, cfg),
 		eventHandlers:   make(map[models.EventType]EventHandler),
+		sessions:        NewSessions(cfg.SessionGracePeriod, cfg.SessionBufferFrames, cfg.SessionBufferAge),
 	}
 
 	// Register event handlers
@@ -140,6 +164,12 @@ func NewHub(redis *redisService.Service, authService *auth.Service, cfg *config.
 	return hub
 }
 
+// UseDashboards enables pushing the live response counts of forms to the
+// clients joining their dashboards
+func (h *Hub) UseDashboards(dashboards *Dashboards) {
+	h.dashboards = dashboards
+}
+
 // NewRateLimiter creates a new rate limiter
 func NewRateLimiter(redis *redisService.Service, config *config.WebSocketConfig) *RateLimiter {
 	return &RateLimiter{
@@ -192,7 +222,7 @@ func (h *Hub) ServeWS(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Authenticate the connection
-	user, err := h.authenticateConnection(r)
+	user, token, err := h.authenticateConnection(r)
 	if err != nil {
 		h.logger.Error("Authentication failed", zap.Error(err))
 		conn.Close()
@@ -200,7 +230,10 @@ func (h *Hub) ServeWS(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create client
-	client := h.createClient(conn, user, r)
+	client := h.createClient(conn, user, token, r)
+
+	// Resume the session of a client reconnecting, or start one
+	h.openSession(client, r.URL.Query())
 
 	// Register client with hub
 	h.register <- client
@@ -210,8 +243,9 @@ func (h *Hub) ServeWS(w http.ResponseWriter, r *http.Request) {
 	go client.readPump()
 }
 
-// authenticateConnection authenticates a WebSocket connection
-func (h *Hub) authenticateConnection(r *http.Request) (*models.User, error) {
+// authenticateConnection authenticates a WebSocket connection, returning its
+// user and token
+func (h *Hub) authenticateConnection(r *http.Request) (*models.User, string, error) {
 	// Extract token from query parameters or headers
 	token := auth.ExtractTokenFromQuery(r.URL.Query())
 	if token == "" {
@@ -219,31 +253,32 @@ func (h *Hub) authenticateConnection(r *http.Request) (*models.User, error) {
 	}
 
 	if token == "" {
-		return nil, fmt.Errorf("no authentication token provided")
+		return nil, "", fmt.Errorf("no authentication token provided")
 	}
 
 	// Validate token
 	claims, err := h.auth.ValidateToken(token)
 	if err != nil {
-		return nil, fmt.Errorf("invalid token: %w", err)
+		return nil, "", fmt.Errorf("invalid token: %w", err)
 	}
 
 	// Create user from claims
 	user := h.auth.CreateUser(claims)
-	return user, nil
+	return user, token, nil
 }
 
 // createClient creates a new WebSocket client
-func (h *Hub) createClient(conn *websocket.Conn, user *models.User, r *http.Request) *Client {
+func (h *Hub) createClient(conn *websocket.Conn, user *models.User, token string, r *http.Request) *Client {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	client := &Client{
 		conn:        conn,
-		send:        make(chan *models.Message, 256),
+		send:        make(chan *models.Message, 256+h.sessions.maxFrames),
 		hub:         h,
 		ID:          uuid.New().String(),
 		UserID:      user.ID,
 		User:        user,
+		token:       token,
 		ConnectedAt: time.Now(),
 		LastPing:    time.Now(),
 		IsActive:    true,
@@ -265,6 +300,65 @@ func (h *Hub) createClient(conn *websocket.Conn, user *models.User, r *http.Requ
 	return client
 }
 
+// openSession resumes the session given in the query, replaying the frames
+// the client missed after the sequence number it saw last. A client whose
+// session can't be resumed is told to reload its state, and starts a new one.
+func (h *Hub) openSession(client *Client, query url.Values) {
+	token := query.Get("session")
+	if token == "" {
+		h.sessions.start(client)
+		return
+	}
+
+	lastSeq, _ := strconv.ParseUint(query.Get("lastSeq"), 10, 64)
+	ended, reason := h.sessions.resume(client, token, lastSeq)
+	if reason == "" {
+		h.metrics.mu.Lock()
+		h.metrics.ResumedSessions++
+		h.metrics.mu.Unlock()
+
+		h.logger.Info("Session resumed",
+			zap.String("clientID", client.ID),
+			zap.String("userID", client.UserID),
+			zap.Uint64("lastSeq", lastSeq))
+		return
+	}
+
+	if ended != nil {
+		h.endSession(ended)
+	}
+	h.metrics.mu.Lock()
+	h.metrics.ResyncedSessions++
+	h.metrics.mu.Unlock()
+
+	h.logger.Info("Session not resumed",
+		zap.String("clientID", client.ID),
+		zap.String("userID", client.UserID),
+		zap.String("reason", reason))
+
+	client.send <- models.NewMessage(models.EventResyncRequired, &models.ResyncRequiredPayload{Reason: reason})
+	h.sessions.start(client)
+}
+
+// endSession removes the user of an ended session from the room it was in,
+// unless another connection of the user is in it
+func (h *Hub) endSession(sess *session) {
+	if sess.formID == "" {
+		return
+	}
+
+	h.mu.RLock()
+	for _, client := range h.userConnections[sess.userID] {
+		if client.FormID == sess.formID {
+			h.mu.RUnlock()
+			return
+		}
+	}
+	h.mu.RUnlock()
+
+	h.removeUserFromRoom(sess.formID, sess.userID)
+}
+
 // registerClient registers a new client
 func (h *Hub) registerClient(client *Client) {
 	h.mu.Lock()
@@ -278,6 +372,7 @@ func (h *Hub) registerClient(client *Client) {
 		h.userConnections[client.UserID] = make([]*Client, 0)
 	}
 	h.userConnections[client.UserID] = append(h.userConnections[client.UserID], client)
+	h.sessions.attached(client)
 
 	// Update metrics
 	h.metrics.mu.Lock()
@@ -329,14 +424,21 @@ func (h *Hub) unregisterClient(client *Client) {
 			delete(h.userConnections, client.UserID)
 		}
 
+		// Stop watching the dashboard before the send channel is closed
+		if h.dashboards != nil {
+			h.dashboards.Leave(client)
+		}
+
 		// Close send channel
 		close(client.send)
 
 		// Cancel client context
 		client.cancel()
 
-		// Remove from current room
-		if client.FormID != "" {
+		// Remove from current room, unless the session is kept for the
+		// client to resume
+		kept := h.sessions.detach(client)
+		if client.FormID != "" && !kept {
 			h.removeUserFromRoom(client.FormID, client.UserID)
 		}
 
@@ -385,37 +487,39 @@ func (h *Hub) broadcastToRoom(formID string, message *models.Message) {
 	}
 }
 
-// sendToUser sends a message to all connections of a specific user
+// sendToUser sends a message to all connections of a specific user, and
+// keeps it for the sessions the user is reconnecting to
 func (h *Hub) sendToUser(userID string, message *models.Message) {
+	var slow []*Client
 	h.mu.RLock()
-	connections := h.userConnections[userID]
+	for _, client := range h.userConnections[userID] {
+		if !client.deliver(message) {
+			slow = append(slow, client)
+		}
+	}
+	h.sessions.buffer(userID, message)
 	h.mu.RUnlock()
 
-	for _, client := range connections {
-		select {
-		case client.send <- message:
-		default:
-			// Client's send channel is full, close it
-			h.unregisterClient(client)
-		}
+	// Close the clients whose send channel is full
+	for _, client := range slow {
+		h.unregisterClient(client)
 	}
 }
 
 // broadcastToAll broadcasts a message to all connected clients
 func (h *Hub) broadcastToAll(message *models.Message) {
+	var slow []*Client
 	h.mu.RLock()
-	clients := make([]*Client, 0, len(h.clients))
 	for client := range h.clients {
-		clients = append(clients, client)
+		if !client.deliver(message) {
+			slow = append(slow, client)
+		}
 	}
+	h.sessions.bufferAll(message)
 	h.mu.RUnlock()
 
-	for _, client := range clients {
-		select {
-		case client.send <- message:
-		default:
-			h.unregisterClient(client)
-		}
+	for _, client := range slow {
+		h.unregisterClient(client)
 	}
 }
 
@@ -453,6 +557,11 @@ func (c *Client) readPump() {
 				continue
 			}
 
+			// Drop the messages a client sends again after reconnecting
+			if message.ClientMsgID != "" && c.session != nil && !c.hub.sessions.accept(c.session, message.ClientMsgID) {
+				continue
+			}
+
 			// Set message metadata
 			message.UserID = c.UserID
 			message.Timestamp = time.Now()
@@ -583,6 +692,11 @@ func (h *Hub) checkOrigin(r *http.Request) bool {
 
 // cleanup performs periodic cleanup tasks
 func (h *Hub) cleanup() {
+	// End the sessions not resumed in time
+	for _, sess := range h.sessions.expire() {
+		h.endSession(sess)
+	}
+
 	// Clean up inactive rooms
 	h.cleanupInactiveRooms()
 
@@ -636,6 +750,8 @@ func (h *Hub) GetMetrics() *Metrics {
 		ActiveRooms:       h.metrics.ActiveRooms,
 		MessagesPerSecond: h.metrics.MessagesPerSecond,
 		ErrorsPerSecond:   h.metrics.ErrorsPerSecond,
+		ResumedSessions:   h.metrics.ResumedSessions,
+		ResyncedSessions:  h.metrics.ResyncedSessions,
 	}
 }
 
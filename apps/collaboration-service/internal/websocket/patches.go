@@ -0,0 +1,86 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/kamkaiz/x-form-backend/collaboration-service/internal/models"
+	"go.uber.org/zap"
+)
+
+// PatchSubscriber subscribes to the patches the form service merges into
+// every form
+type PatchSubscriber interface {
+	SubscribeFormPatches(ctx context.Context) (<-chan []byte, func() error, error)
+}
+
+// formPatch is a patch published by the form service
+type formPatch struct {
+	FormID          string                  `json:"form_id"`
+	ActorID         string                  `json:"actor_id"`
+	PreviousVersion int                     `json:"previous_version"`
+	Version         int                     `json:"version"`
+	Operations      []models.PatchOperation `json:"operations"`
+	CreatedAt       time.Time               `json:"created_at"`
+}
+
+// ForwardPatches pushes the patches merged into forms to the clients in
+// their rooms as form:patch frames until ctx is done, as ForwardActivity
+// does with their activity
+func (h *Hub) ForwardPatches(ctx context.Context, subscriber PatchSubscriber) error {
+	patches, unsubscribe, err := subscriber.SubscribeFormPatches(ctx)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		defer func() {
+			if err := unsubscribe(); err != nil {
+				h.logger.Warn("Failed to unsubscribe from form patches", zap.Error(err))
+			}
+		}()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case data, ok := <-patches:
+				if !ok {
+					return
+				}
+				message, ok := h.patchFrame(data)
+				if !ok {
+					continue
+				}
+				select {
+				case h.broadcast <- message:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// patchFrame returns the form:patch frame of a patch published by the form
+// service, addressed to the room of its form
+func (h *Hub) patchFrame(data []byte) (*models.Message, bool) {
+	var patch formPatch
+	if err := json.Unmarshal(data, &patch); err != nil || patch.FormID == "" || patch.Version == 0 {
+		h.logger.Warn("Dropping invalid form patch")
+		return nil, false
+	}
+
+	message := models.NewMessage(models.EventFormPatch, &models.FormPatchPayload{
+		FormID:          patch.FormID,
+		ActorID:         patch.ActorID,
+		PreviousVersion: patch.PreviousVersion,
+		Version:         patch.Version,
+		Operations:      patch.Operations,
+		CreatedAt:       patch.CreatedAt,
+	})
+	message.FormID = patch.FormID
+	message.UserID = patch.ActorID
+	return message, true
+}
@@ -0,0 +1,65 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kamkaiz/x-form-backend/collaboration-service/internal/config"
+	"github.com/kamkaiz/x-form-backend/collaboration-service/internal/models"
+	"go.uber.org/zap"
+)
+
+// patchChannel delivers the patches sent on it
+type patchChannel chan []byte
+
+func (c patchChannel) SubscribeFormPatches(context.Context) (<-chan []byte, func() error, error) {
+	return c, func() error { return nil }, nil
+}
+
+func TestForwardPatches(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hub := NewHub(nil, nil, &config.WebSocketConfig{}, zap.NewNop())
+	builder := joinedClient(hub, "form-1", "editor")
+	elsewhere := joinedClient(hub, "form-2", "viewer")
+	go hub.Run(ctx)
+
+	patches := make(patchChannel)
+	if err := hub.ForwardPatches(ctx, patches); err != nil {
+		t.Fatal(err)
+	}
+	patches <- []byte("not json")
+	patches <- []byte(`{"form_id":"form-1","actor_id":"owner","previous_version":7,"version":8,` +
+		`"operations":[{"op":"replace","path":"/title","value":"Product feedback"}],"created_at":"2026-03-01T12:00:00Z"}`)
+
+	select {
+	case message := <-builder.send:
+		payload, ok := message.Payload.(*models.FormPatchPayload)
+		if message.Type != models.EventFormPatch || !ok {
+			t.Fatalf("got %s frame %+v, want form:patch", message.Type, message.Payload)
+		}
+		want := &models.FormPatchPayload{
+			FormID:          "form-1",
+			ActorID:         "owner",
+			PreviousVersion: 7,
+			Version:         8,
+			Operations:      []models.PatchOperation{{Op: "replace", Path: "/title", Value: json.RawMessage(`"Product feedback"`)}},
+			CreatedAt:       time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		}
+		if !reflect.DeepEqual(payload, want) {
+			t.Errorf("patch = %+v, want %+v", payload, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no form:patch frame sent to the room of the form")
+	}
+
+	select {
+	case message := <-elsewhere.send:
+		t.Errorf("client of another form got %s frame", message.Type)
+	default:
+	}
+}
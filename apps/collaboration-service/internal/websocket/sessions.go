@@ -0,0 +1,339 @@
+package websocket
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/kamkaiz/x-form-backend/collaboration-service/internal/models"
+)
+
+// Reasons a reconnecting client has to reload its state
+const (
+	ResyncUnknownSession = "unknown_session"
+	ResyncExpired        = "expired"
+	ResyncFramesDropped  = "frames_dropped"
+)
+
+// session outlives the connections of a client for a grace period, so a
+// client reconnecting after a network blip receives the frames it missed.
+// Every frame sent in a session gets the next sequence number and is kept
+// for replay, up to a bound.
+type session struct {
+	token  string
+	userID string
+
+	mu sync.Mutex
+	// client is the connection of the session, nil while disconnected
+	client *Client
+	// formID is the room the session is in, kept while disconnected
+	formID       string
+	seq          uint64
+	frames       []sessionFrame
+	received     map[string]time.Time
+	disconnected time.Time
+}
+
+// sessionFrame is a frame kept for replay
+type sessionFrame struct {
+	message *models.Message
+	at      time.Time
+}
+
+// Sessions keeps the sessions of the clients of a hub
+type Sessions struct {
+	mu     sync.Mutex
+	tokens map[string]*session
+	// detached are the disconnected sessions of each user, buffering the
+	// frames sent to the user
+	detached map[string]map[*session]bool
+
+	// grace is how long a disconnected session can be resumed, zero to
+	// end sessions with their connection
+	grace     time.Duration
+	maxFrames int
+	maxAge    time.Duration
+	now       func() time.Time
+}
+
+// NewSessions keeps disconnected sessions for grace, each with up to
+// maxFrames frames of the last maxAge
+func NewSessions(grace time.Duration, maxFrames int, maxAge time.Duration) *Sessions {
+	return &Sessions{
+		tokens:    make(map[string]*session),
+		detached:  make(map[string]map[*session]bool),
+		grace:     grace,
+		maxFrames: maxFrames,
+		maxAge:    maxAge,
+		now:       time.Now,
+	}
+}
+
+// start begins a new session for the client, telling it its token
+func (s *Sessions) start(client *Client) {
+	b := make([]byte, 24)
+	_, _ = rand.Read(b)
+	sess := &session{
+		token:    hex.EncodeToString(b),
+		userID:   client.UserID,
+		client:   client,
+		received: make(map[string]time.Time),
+	}
+	client.session = sess
+
+	s.mu.Lock()
+	s.tokens[sess.token] = sess
+	s.mu.Unlock()
+
+	client.send <- sessionMessage(sess, false)
+}
+
+// resume attaches the client to the session of token, queueing the frames
+// sent after lastSeq. It fails with the reason the client has to reload its
+// state; the session is ended then, and returned when it existed.
+func (s *Sessions) resume(client *Client, token string, lastSeq uint64) (*session, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.tokens[token]
+	if !ok || sess.userID != client.UserID {
+		return nil, ResyncUnknownSession
+	}
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if sess.client != nil {
+		// The old connection is not known to be gone yet
+		sess.formID = sess.client.FormID
+	} else if s.now().Sub(sess.disconnected) > s.grace {
+		s.end(sess)
+		return sess, ResyncExpired
+	}
+	s.trim(sess)
+	if lastSeq > sess.seq || (len(sess.frames) > 0 && lastSeq+1 < sess.frames[0].message.Seq) ||
+		(len(sess.frames) == 0 && lastSeq < sess.seq) {
+		s.end(sess)
+		return sess, ResyncFramesDropped
+	}
+
+	sess.client = client
+	client.session = sess
+	client.FormID = sess.formID
+	client.send <- sessionMessage(sess, true)
+	for _, frame := range sess.frames {
+		if frame.message.Seq > lastSeq {
+			client.send <- frame.message
+		}
+	}
+	// The session stays among the disconnected ones until the client is
+	// registered, so the frames sent meanwhile reach it
+	s.detachLocked(sess)
+	return sess, ""
+}
+
+// attached removes the session of the client registered from the
+// disconnected ones, as the connections of the user reach it now
+func (s *Sessions) attached(client *Client) {
+	if client.session == nil {
+		return
+	}
+	s.mu.Lock()
+	s.undetach(client.session)
+	s.mu.Unlock()
+}
+
+// deliver sends the message to the client of the session, numbered and kept
+// for replay, reporting false when the client can't take it. A disconnected
+// session keeps the message for the client resuming it; a connection whose
+// session was resumed by another gets nothing. from is nil for messages to
+// whichever client the session has.
+func (s *Sessions) deliver(sess *session, from *Client, message *models.Message) bool {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	if from != nil && sess.client != from {
+		return true
+	}
+	framed := s.record(sess, message)
+	if sess.client == nil {
+		return true
+	}
+	select {
+	case sess.client.send <- framed:
+		return true
+	default:
+		return false
+	}
+}
+
+// record numbers a copy of the message and keeps it for replay, as messages
+// are shared between the clients they are broadcast to
+func (s *Sessions) record(sess *session, message *models.Message) *models.Message {
+	framed := *message
+	sess.seq++
+	framed.Seq = sess.seq
+	sess.frames = append(sess.frames, sessionFrame{message: &framed, at: s.now()})
+	s.trim(sess)
+	return &framed
+}
+
+// trim drops the frames past the bounds of the buffer
+func (s *Sessions) trim(sess *session) {
+	drop := 0
+	if over := len(sess.frames) - s.maxFrames; over > 0 {
+		drop = over
+	}
+	cutoff := s.now().Add(-s.maxAge)
+	for drop < len(sess.frames) && sess.frames[drop].at.Before(cutoff) {
+		drop++
+	}
+	if drop > 0 {
+		sess.frames = append(sess.frames[:0], sess.frames[drop:]...)
+	}
+}
+
+// buffer keeps the message for the disconnected sessions of the user
+func (s *Sessions) buffer(userID string, message *models.Message) {
+	s.mu.Lock()
+	sessions := make([]*session, 0, len(s.detached[userID]))
+	for sess := range s.detached[userID] {
+		sessions = append(sessions, sess)
+	}
+	s.mu.Unlock()
+
+	for _, sess := range sessions {
+		s.deliver(sess, nil, message)
+	}
+}
+
+// bufferAll keeps the message for every disconnected session
+func (s *Sessions) bufferAll(message *models.Message) {
+	s.mu.Lock()
+	var sessions []*session
+	for _, byUser := range s.detached {
+		for sess := range byUser {
+			sessions = append(sessions, sess)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, sess := range sessions {
+		s.deliver(sess, nil, message)
+	}
+}
+
+// detach keeps the session of the client disconnecting for the grace
+// period, reporting whether the session lives on, kept or resumed by
+// another connection
+func (s *Sessions) detach(client *Client) bool {
+	sess := client.session
+	if sess == nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if sess.client != client {
+		return sess.client != nil
+	}
+	sess.client = nil
+	sess.formID = client.FormID
+	if s.grace <= 0 {
+		s.end(sess)
+		return false
+	}
+	sess.disconnected = s.now()
+	s.detachLocked(sess)
+	return true
+}
+
+// detachLocked adds the session to the disconnected ones; s.mu must be held
+func (s *Sessions) detachLocked(sess *session) {
+	if s.detached[sess.userID] == nil {
+		s.detached[sess.userID] = make(map[*session]bool)
+	}
+	s.detached[sess.userID][sess] = true
+}
+
+// expire ends the sessions disconnected for longer than the grace period,
+// returning them
+func (s *Sessions) expire() []*session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expired []*session
+	for _, byUser := range s.detached {
+		for sess := range byUser {
+			sess.mu.Lock()
+			if sess.client == nil && s.now().Sub(sess.disconnected) > s.grace {
+				s.end(sess)
+				expired = append(expired, sess)
+			}
+			sess.mu.Unlock()
+		}
+	}
+	return expired
+}
+
+// end forgets the session; s.mu must be held
+func (s *Sessions) end(sess *session) {
+	delete(s.tokens, sess.token)
+	s.undetach(sess)
+}
+
+// undetach removes the session from the disconnected ones; s.mu must be held
+func (s *Sessions) undetach(sess *session) {
+	byUser := s.detached[sess.userID]
+	delete(byUser, sess)
+	if len(byUser) == 0 {
+		delete(s.detached, sess.userID)
+	}
+}
+
+// accept records the ID a client gave a message, reporting false when the
+// session accepted it already, as when the client sends again the messages
+// it queued while disconnected
+func (s *Sessions) accept(sess *session, clientMsgID string) bool {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	now := s.now()
+	if _, ok := sess.received[clientMsgID]; ok {
+		return false
+	}
+	// IDs are kept as long as a client could send them again
+	for id, at := range sess.received {
+		if now.Sub(at) > s.grace+s.maxAge {
+			delete(sess.received, id)
+		}
+	}
+	sess.received[clientMsgID] = now
+	return true
+}
+
+// sessionMessage returns the frame telling the client its session; sess.mu
+// must be held once the session is shared
+func sessionMessage(sess *session, resumed bool) *models.Message {
+	return models.NewMessage(models.EventSession, &models.SessionPayload{
+		SessionToken: sess.token,
+		Resumed:      resumed,
+		LastSeq:      sess.seq,
+	})
+}
+
+// deliver queues the message for the client, numbered in its session,
+// reporting false when the client is too slow to take it
+func (c *Client) deliver(message *models.Message) bool {
+	if c.session == nil {
+		select {
+		case c.send <- message:
+			return true
+		default:
+			return false
+		}
+	}
+	return c.hub.sessions.deliver(c.session, c, message)
+}
@@ -0,0 +1,197 @@
+package websocket
+
+import (
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/kamkaiz/x-form-backend/collaboration-service/internal/config"
+	"github.com/kamkaiz/x-form-backend/collaboration-service/internal/models"
+	"go.uber.org/zap"
+)
+
+// sessionHub returns a hub keeping sessions for a minute, with a clock the
+// test advances
+func sessionHub(frames int) (*Hub, *time.Time) {
+	hub := NewHub(nil, nil, &config.WebSocketConfig{
+		SessionGracePeriod:  time.Minute,
+		SessionBufferFrames: frames,
+		SessionBufferAge:    time.Minute,
+	}, zap.NewNop())
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	hub.sessions.now = func() time.Time { return now }
+	return hub, &now
+}
+
+// connect opens a session for a new connection of the user, resuming token
+// after lastSeq when given
+func connect(h *Hub, userID, token string, lastSeq uint64) *Client {
+	client := &Client{ID: userID, UserID: userID, hub: h, send: make(chan *models.Message, 256+h.sessions.maxFrames)}
+	query := url.Values{}
+	if token != "" {
+		query.Set("session", token)
+		query.Set("lastSeq", strconv.FormatUint(lastSeq, 10))
+	}
+	h.openSession(client, query)
+	h.mu.Lock()
+	h.userConnections[userID] = append(h.userConnections[userID], client)
+	h.sessions.attached(client)
+	h.mu.Unlock()
+	return client
+}
+
+// disconnect drops the connection, keeping its session
+func disconnect(h *Hub, client *Client) {
+	h.mu.Lock()
+	delete(h.userConnections, client.UserID)
+	h.sessions.detach(client)
+	h.mu.Unlock()
+}
+
+// frames returns the frames queued for the client
+func frames(client *Client) []*models.Message {
+	var queued []*models.Message
+	for {
+		select {
+		case message := <-client.send:
+			queued = append(queued, message)
+		default:
+			return queued
+		}
+	}
+}
+
+func sessionToken(t *testing.T, message *models.Message, resumed bool) string {
+	t.Helper()
+	payload, ok := message.Payload.(*models.SessionPayload)
+	if message.Type != models.EventSession || !ok || payload.Resumed != resumed {
+		t.Fatalf("expected a session frame resumed %v, got %+v", resumed, message)
+	}
+	return payload.SessionToken
+}
+
+func update(n int) *models.Message {
+	return models.NewMessage(models.EventFormUpdate, n)
+}
+
+func TestSessionResumeReplaysMissedFrames(t *testing.T) {
+	hub, _ := sessionHub(500)
+
+	client := connect(hub, "editor", "", 0)
+	hub.sendToUser("editor", update(1))
+	hub.sendToUser("editor", update(2))
+	seen := frames(client)
+	if len(seen) != 3 || seen[1].Seq != 1 || seen[2].Seq != 2 {
+		t.Fatalf("expected the session and two numbered frames, got %+v", seen)
+	}
+	token := sessionToken(t, seen[0], false)
+
+	// The second frame is lost with the connection
+	disconnect(hub, client)
+	hub.sendToUser("editor", update(3))
+	hub.sendToUser("editor", update(4))
+
+	resumed := connect(hub, "editor", token, 1)
+	replayed := frames(resumed)
+	if sessionToken(t, replayed[0], true) != token {
+		t.Fatal("expected the session to keep its token")
+	}
+	replayed = replayed[1:]
+	if len(replayed) != 3 {
+		t.Fatalf("expected the three missed frames, got %d", len(replayed))
+	}
+	for i, message := range replayed {
+		if message.Seq != uint64(i+2) || message.Payload != i+2 {
+			t.Fatalf("expected frame %d in order, got seq %d payload %v", i+2, message.Seq, message.Payload)
+		}
+	}
+
+	hub.sendToUser("editor", update(5))
+	if next := frames(resumed); len(next) != 1 || next[0].Seq != 5 {
+		t.Fatalf("expected the session to go on numbering frames, got %+v", next)
+	}
+	if metrics := hub.GetMetrics(); metrics.ResumedSessions != 1 || metrics.ResyncedSessions != 0 {
+		t.Fatalf("unexpected metrics %+v", metrics)
+	}
+}
+
+func TestSessionExpiredRequiresResync(t *testing.T) {
+	hub, now := sessionHub(500)
+
+	client := connect(hub, "editor", "", 0)
+	token := sessionToken(t, frames(client)[0], false)
+	disconnect(hub, client)
+	hub.sendToUser("editor", update(1))
+
+	*now = now.Add(time.Minute + time.Second)
+	reconnected := connect(hub, "editor", token, 0)
+	seen := frames(reconnected)
+	if len(seen) != 2 || seen[0].Type != models.EventResyncRequired {
+		t.Fatalf("expected resync_required then a new session, got %+v", seen)
+	}
+	if payload := seen[0].Payload.(*models.ResyncRequiredPayload); payload.Reason != ResyncExpired {
+		t.Fatalf("expected the session to have expired, got %q", payload.Reason)
+	}
+	if sessionToken(t, seen[1], false) == token {
+		t.Fatal("expected a new session token")
+	}
+
+	// Sessions are also expired by the hub before anyone reconnects
+	disconnect(hub, reconnected)
+	*now = now.Add(30 * time.Second)
+	if expired := hub.sessions.expire(); len(expired) != 0 {
+		t.Fatalf("expected the session to be kept within the grace period, expired %d", len(expired))
+	}
+	*now = now.Add(31 * time.Second)
+	if expired := hub.sessions.expire(); len(expired) != 1 {
+		t.Fatalf("expected the session to expire, expired %d", len(expired))
+	}
+	if len(hub.sessions.tokens) != 0 || len(hub.sessions.detached) != 0 {
+		t.Fatal("expected expired sessions to be forgotten")
+	}
+
+	if metrics := hub.GetMetrics(); metrics.ResumedSessions != 0 || metrics.ResyncedSessions != 1 {
+		t.Fatalf("unexpected metrics %+v", metrics)
+	}
+}
+
+func TestSessionResyncWhenFramesWereDropped(t *testing.T) {
+	hub, _ := sessionHub(2)
+
+	client := connect(hub, "editor", "", 0)
+	token := sessionToken(t, frames(client)[0], false)
+	disconnect(hub, client)
+	for i := 1; i <= 3; i++ {
+		hub.sendToUser("editor", update(i))
+	}
+
+	seen := frames(connect(hub, "editor", token, 0))
+	if len(seen) != 2 || seen[0].Type != models.EventResyncRequired {
+		t.Fatalf("expected resync_required then a new session, got %+v", seen)
+	}
+	if payload := seen[0].Payload.(*models.ResyncRequiredPayload); payload.Reason != ResyncFramesDropped {
+		t.Fatalf("expected frames to have been dropped, got %q", payload.Reason)
+	}
+}
+
+func TestSessionDropsResentClientMessages(t *testing.T) {
+	hub, now := sessionHub(500)
+
+	client := connect(hub, "editor", "", 0)
+	token := sessionToken(t, frames(client)[0], false)
+	if !hub.sessions.accept(client.session, "msg-1") {
+		t.Fatal("expected the first message to be accepted")
+	}
+	disconnect(hub, client)
+
+	// The client sends the messages it queued while disconnected again
+	*now = now.Add(10 * time.Second)
+	resumed := connect(hub, "editor", token, 0)
+	if hub.sessions.accept(resumed.session, "msg-1") {
+		t.Fatal("expected a message sent again to be dropped")
+	}
+	if !hub.sessions.accept(resumed.session, "msg-2") {
+		t.Fatal("expected a message sent while disconnected to be accepted")
+	}
+}
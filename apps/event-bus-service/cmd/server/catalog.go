@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/acl"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/catalog"
+)
+
+// catalogPath prefixes the event type catalog endpoints
+const catalogPath = "/catalog"
+
+// CatalogRegistrationRequest registers a service with the catalog
+type CatalogRegistrationRequest struct {
+	Service   string   `json:"service" example:"form-service"`
+	Produces  []string `json:"produces" example:"form.published"`
+	Consumes  []string `json:"consumes" example:"form.response.created"`
+	SchemaRef string   `json:"schema_ref,omitempty" example:"https://github.com/Mir00r/X-Form-Backend/tree/main/schemas/form-service"`
+	Team      string   `json:"team,omitempty" example:"forms"`
+}
+
+// RegisterCatalog registers the event types a service produces and consumes
+//
+// @Summary     Register with the event type catalog
+// @Description Requires event_processing.catalog. Creates or replaces the registration of the service: the event types it produces and consumes, where the schemas of those it produces are and the team to contact. Registering again is the heartbeat of the service; registrations without a heartbeat nor traffic of their event types for event_processing.catalog.stale_after are flagged stale. Services register at startup, with the eventbus.CatalogClient of the shared client, or are declared in event_processing.catalog.registrations. With security.publish_acl, callers must present credentials.
+// @Tags        catalog
+// @Accept      json
+// @Produce     json
+// @Param       registration body     CatalogRegistrationRequest true "Registration"
+// @Success     200          {object} APIResponse{data=catalog.Registration}
+// @Failure     400          {object} ErrorResponse
+// @Failure     401          {object} ErrorResponse
+// @Failure     405          {object} ErrorResponse
+// @Failure     500          {object} ErrorResponse
+// @Failure     503          {object} ErrorResponse "The catalog is not enabled"
+// @Router      /catalog/register [post]
+func (h *EventBusHandler) RegisterCatalog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
+		return
+	}
+	if h.catalog == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "The event type catalog is not enabled", nil)
+		return
+	}
+	if h.auth != nil {
+		if caller, _ := acl.FromContext(h.withCaller(r)); caller == nil || len(caller.Principals) == 0 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			h.respondError(w, http.StatusUnauthorized, "Credentials are required to register", nil)
+			return
+		}
+	}
+
+	var req CatalogRegistrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	registration, err := h.catalog.Register(r.Context(), catalog.Registration{
+		Service:   req.Service,
+		Produces:  req.Produces,
+		Consumes:  req.Consumes,
+		SchemaRef: req.SchemaRef,
+		Team:      req.Team,
+	})
+	if errors.Is(err, catalog.ErrInvalidRegistration) {
+		h.respondError(w, http.StatusBadRequest, "Invalid registration", err)
+		return
+	}
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to register", err)
+		return
+	}
+	h.respondSuccess(w, registration, "Service registered successfully")
+}
+
+// ListCatalogEventTypes returns the event type catalog
+//
+// @Summary     List the event types of the catalog
+// @Description Requires event_processing.catalog. Lists every event type registered or seen in traffic, by name, with the services registered as its producers and consumers, the latest version of its schema in the schema registry (with kafka.schema_registry.compatibility), when an event of the type was last published or consumed by the service and a sample payload. Samples keep the shape of the data of a recent event, its values replaced by placeholders of their type. Stale registrations are flagged. The JSON is the contract of the developer portal: fields are only ever added.
+// @Tags        catalog
+// @Produce     json
+// @Success     200 {object} APIResponse{data=catalog.Report}
+// @Failure     405 {object} ErrorResponse
+// @Failure     500 {object} ErrorResponse
+// @Failure     503 {object} ErrorResponse "The catalog is not enabled"
+// @Router      /catalog/event-types [get]
+func (h *EventBusHandler) ListCatalogEventTypes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
+		return
+	}
+	if h.catalog == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "The event type catalog is not enabled", nil)
+		return
+	}
+
+	report, err := h.catalog.Report(r.Context())
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to read the event type catalog", err)
+		return
+	}
+	h.respondSuccess(w, report, "Event type catalog retrieved successfully")
+}
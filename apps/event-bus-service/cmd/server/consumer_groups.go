@@ -0,0 +1,246 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/audit"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/publishing"
+)
+
+// consumerGroupsPath prefixes the consumer group admin endpoints
+const consumerGroupsPath = "/admin/consumer-groups"
+
+// ListConsumerGroups lists the consumer groups of the cluster
+//
+// @Summary     List consumer groups
+// @Description Requires one of security.admin_keys. Groups are sorted by ID, with their members and the partitions assigned to them.
+// @Tags        admin
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Success     200 {object} APIResponse{data=[]kafka.ConsumerGroup}
+// @Failure     401 {object} ErrorResponse
+// @Failure     405 {object} ErrorResponse
+// @Failure     500 {object} ErrorResponse
+// @Router      /admin/consumer-groups [get]
+func (h *EventBusHandler) ListConsumerGroups(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
+		return
+	}
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+
+	groups, err := h.kafka.ListConsumerGroups(r.Context())
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to list consumer groups", err)
+		return
+	}
+	h.respondSuccess(w, groups, "Consumer groups retrieved successfully")
+}
+
+// ConsumerGroup serves the endpoints of a consumer group, under the
+// /admin/consumer-groups/ subtree
+func (h *EventBusHandler) ConsumerGroup(w http.ResponseWriter, r *http.Request) {
+	group, action, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, consumerGroupsPath+"/"), "/")
+	if !ok || group == "" {
+		h.respondError(w, http.StatusNotFound, "Not found", nil)
+		return
+	}
+	switch action {
+	case "offsets":
+		h.GetConsumerGroupOffsets(w, r, group)
+	case "reset":
+		h.ResetConsumerGroupOffsets(w, r, group)
+	default:
+		h.respondError(w, http.StatusNotFound, "Not found", nil)
+	}
+}
+
+// GetConsumerGroupOffsets reports the committed offsets and lag of a group
+//
+// @Summary     Consumer group offsets
+// @Description Requires one of security.admin_keys. Covers the partitions the group committed offsets for or is assigned. committed is -1 and lag null for partitions without a committed offset.
+// @Tags        admin
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       group path     string true "Consumer group ID"
+// @Success     200   {object} APIResponse{data=kafka.GroupOffsets}
+// @Failure     401   {object} ErrorResponse
+// @Failure     404   {object} ErrorResponse
+// @Failure     405   {object} ErrorResponse
+// @Failure     500   {object} ErrorResponse
+// @Router      /admin/consumer-groups/{group}/offsets [get]
+func (h *EventBusHandler) GetConsumerGroupOffsets(w http.ResponseWriter, r *http.Request, group string) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
+		return
+	}
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+
+	offsets, err := h.kafka.GetConsumerGroupOffsets(r.Context(), group)
+	if errors.Is(err, kafka.ErrConsumerGroupNotFound) {
+		h.respondError(w, http.StatusNotFound, "Consumer group not found", err)
+		return
+	}
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to get consumer group offsets", err)
+		return
+	}
+	h.respondSuccess(w, offsets, "Consumer group offsets retrieved successfully")
+}
+
+// ResetConsumerGroupOffsets moves the committed offsets of a group
+//
+// @Summary     Reset consumer group offsets
+// @Description Requires one of security.admin_keys. strategy is earliest, latest, timestamp or offsets. timestamp resets each partition to its first message at or after the time, or to its end when there is none; offsets sets the given offsets, clamped to the retained log. topics defaults to the topics the group committed offsets for. Groups with members are refused unless force is true, also accepted as ?force=true; even forced, Kafka refuses commits while members are joined. Every reset is recorded as an audit.consumer_group.offsets_reset event.
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       group   path     string            true  "Consumer group ID"
+// @Param       force   query    bool              false "Reset a group that has members"
+// @Param       request body     kafka.OffsetReset true  "Reset"
+// @Success     200     {object} APIResponse{data=kafka.OffsetResetResult}
+// @Failure     400     {object} ErrorResponse
+// @Failure     401     {object} ErrorResponse
+// @Failure     404     {object} ErrorResponse "The group or a topic does not exist"
+// @Failure     405     {object} ErrorResponse
+// @Failure     409     {object} ErrorResponse "The group has members"
+// @Failure     500     {object} ErrorResponse
+// @Router      /admin/consumer-groups/{group}/reset [post]
+func (h *EventBusHandler) ResetConsumerGroupOffsets(w http.ResponseWriter, r *http.Request, group string) {
+	if r.Method != http.MethodPost {
+		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
+		return
+	}
+	actor, ok := h.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var reset kafka.OffsetReset
+	if err := json.NewDecoder(r.Body).Decode(&reset); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid JSON payload", err)
+		return
+	}
+	if r.URL.Query().Get("force") == "true" {
+		reset.Force = true
+	}
+
+	result, err := h.kafka.ResetConsumerGroupOffsets(r.Context(), group, reset)
+	switch {
+	case errors.Is(err, kafka.ErrConsumerGroupNotFound):
+		h.respondError(w, http.StatusNotFound, "Consumer group not found", err)
+		return
+	case errors.Is(err, kafka.ErrTopicNotFound):
+		h.respondError(w, http.StatusNotFound, "Topic not found", err)
+		return
+	case errors.Is(err, kafka.ErrConsumerGroupActive):
+		h.respondError(w, http.StatusConflict, "Consumer group has members", err)
+		return
+	case errors.Is(err, kafka.ErrInvalidOffsetReset):
+		h.respondError(w, http.StatusBadRequest, "Invalid offset reset", err)
+		return
+	case err != nil:
+		h.respondError(w, http.StatusInternalServerError, "Failed to reset consumer group offsets", err)
+		return
+	}
+
+	h.logger.Info("Consumer group offsets reset",
+		zap.String("group", group),
+		zap.String("actor", actor),
+		zap.String("strategy", result.Strategy),
+		zap.Bool("forced", result.Forced),
+		zap.Int("partitions", len(result.Partitions)))
+	h.auditOffsetReset(r, actor, result)
+	h.respondSuccess(w, result, "Consumer group offsets reset")
+}
+
+// auditOffsetReset publishes the audit event of a reset. The offsets are
+// committed by then, so a failure to publish is logged rather than
+// reported to the caller.
+func (h *EventBusHandler) auditOffsetReset(r *http.Request, actor string, result *kafka.OffsetResetResult) {
+	topics := h.config.EventProcessing.Audit.Topics
+	if h.publisher == nil || len(topics) == 0 {
+		h.logger.Warn("Consumer group offsets reset without an audit event", zap.String("group", result.GroupID))
+		return
+	}
+
+	before := make(map[string]map[string]int64)
+	after := make(map[string]map[string]int64)
+	for _, p := range result.Partitions {
+		if before[p.Topic] == nil {
+			before[p.Topic] = make(map[string]int64)
+			after[p.Topic] = make(map[string]int64)
+		}
+		partition := fmt.Sprint(p.Partition)
+		before[p.Topic][partition] = p.Previous
+		after[p.Topic][partition] = p.Offset
+	}
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	_, err = h.publisher.Publish(r.Context(), publishing.ProtocolHTTP, &publishing.EventRequest{
+		EventType: audit.EventTypePrefix + "consumer_group.offsets_reset",
+		Source:    "event-bus-service",
+		Subject:   result.GroupID,
+		Topic:     topics[0],
+		Key:       result.GroupID,
+		Data: map[string]interface{}{
+			"actor":          actor,
+			"resource_type":  "consumer_group",
+			"resource_id":    result.GroupID,
+			"before":         map[string]interface{}{"offsets": before},
+			"after":          map[string]interface{}{"offsets": after, "strategy": result.Strategy, "forced": result.Forced},
+			"ip":             ip,
+			"correlation_id": r.Header.Get("X-Request-ID"),
+			"occurred_at":    time.Now().UTC(),
+		},
+	})
+	if err != nil {
+		h.logger.Error("Failed to publish the audit event of an offset reset",
+			zap.String("group", result.GroupID), zap.Error(err))
+	}
+}
+
+// requireAdmin authenticates the request with one of the admin keys, sent
+// as X-API-Key or as a bearer token, and returns the name of the key. It
+// answers 401 itself when the request is not authenticated.
+func (h *EventBusHandler) requireAdmin(w http.ResponseWriter, r *http.Request) (string, bool) {
+	key := r.Header.Get("X-API-Key")
+	if key == "" {
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			key = token
+		}
+	}
+
+	actor := ""
+	if admin := h.config.Security.AdminKeys; admin.Enabled && key != "" {
+		for name, candidate := range admin.Keys {
+			if candidate != "" && subtle.ConstantTimeCompare([]byte(key), []byte(candidate)) == 1 {
+				actor = name
+			}
+		}
+	}
+	if actor == "" {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		h.respondError(w, http.StatusUnauthorized, "A valid admin key is required", nil)
+		return "", false
+	}
+	return actor, true
+}
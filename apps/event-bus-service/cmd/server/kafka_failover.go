@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+)
+
+// kafkaFailoverPath prefixes the Kafka failover admin endpoints
+const kafkaFailoverPath = "/admin/kafka/failover"
+
+// SwitchConsumersRequest names the cluster to move the consumers to
+type SwitchConsumersRequest struct {
+	Cluster string `json:"cluster" enums:"primary,secondary" example:"secondary"`
+}
+
+// GetKafkaFailover reports the state of the failover to the secondary cluster
+//
+// @Summary     Kafka failover state
+// @Description Requires one of security.admin_keys and kafka.failover. The producer fails over to the secondary cluster by itself; the consumers stay where they are until switched with POST /admin/kafka/failover/consumers.
+// @Tags        admin
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Success     200 {object} APIResponse{data=kafka.FailoverStatus}
+// @Failure     401 {object} ErrorResponse
+// @Failure     405 {object} ErrorResponse
+// @Failure     503 {object} ErrorResponse "Failover is not enabled"
+// @Router      /admin/kafka/failover [get]
+func (h *EventBusHandler) GetKafkaFailover(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
+		return
+	}
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+
+	status := h.kafka.FailoverStatus()
+	if status == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "Kafka failover is not enabled", nil)
+		return
+	}
+	h.respondSuccess(w, status, "Kafka failover state retrieved successfully")
+}
+
+// SwitchKafkaConsumers moves the consumer groups to another cluster
+//
+// @Summary     Switch the Kafka consumers
+// @Description Requires one of security.admin_keys and kafka.failover. Consumers never fail over by themselves, as the offsets committed on one cluster do not apply to the other: switch them once the offsets of their groups are in place on the target. The consumer groups of the service rejoin on the target, and later stream subscriptions start there. The consumer group admin endpoints keep reading the primary. A failed switch is completed by switching again.
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       request body     SwitchConsumersRequest true "Target cluster"
+// @Success     200     {object} APIResponse{data=kafka.FailoverStatus}
+// @Failure     400     {object} ErrorResponse
+// @Failure     401     {object} ErrorResponse
+// @Failure     405     {object} ErrorResponse
+// @Failure     500     {object} APIResponse{data=kafka.FailoverStatus} "Some groups did not switch"
+// @Failure     503     {object} ErrorResponse "Failover is not enabled"
+// @Router      /admin/kafka/failover/consumers [post]
+func (h *EventBusHandler) SwitchKafkaConsumers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
+		return
+	}
+	actor, ok := h.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var req SwitchConsumersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid JSON payload", err)
+		return
+	}
+
+	status, err := h.kafka.SwitchConsumers(r.Context(), req.Cluster)
+	switch {
+	case errors.Is(err, kafka.ErrFailoverDisabled):
+		h.respondError(w, http.StatusServiceUnavailable, "Kafka failover is not enabled", err)
+		return
+	case errors.Is(err, kafka.ErrUnknownCluster):
+		h.respondError(w, http.StatusBadRequest, "Unknown cluster", err)
+		return
+	case err != nil:
+		h.respond(w, http.StatusInternalServerError, false, "Failed to switch the consumers", status, err.Error())
+		return
+	}
+
+	h.logger.Warn("Kafka consumers switched by an admin",
+		zap.String("cluster", req.Cluster),
+		zap.String("actor", actor))
+	h.respondSuccess(w, status, "Kafka consumers switched")
+}
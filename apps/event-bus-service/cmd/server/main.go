@@ -4,34 +4,91 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
-
+	// Scheduled report emails show their period in the time zone of the
+	// schedule
+	_ "time/tzdata"
+
+	_ "github.com/Mir00r/X-Form-Backend/services/event-bus-service/docs"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/acl"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/anomaly"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/audit"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/catalog"
 	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
 	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/debezium"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/eventstore"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/grpcserver"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/invalidation"
 	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/livestats"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/notifications"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/privacy"
 	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/processors"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/projections"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/publishing"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/scheduler"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/schemas"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/stream"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/telemetry"
+	"github.com/Mir00r/X-Form-Backend/shared/errreport"
+	"github.com/Mir00r/X-Form-Backend/shared/startup"
+	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
+	httpSwagger "github.com/swaggo/http-swagger"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
 )
 
 // Application represents the main application
 type Application struct {
-	config           *config.Config
-	logger           *zap.Logger
-	kafka            *kafka.Client
-	debezium         *debezium.Manager
-	processorManager *processors.ProcessorManager
-	httpServer       *http.Server
-	metricsServer    *http.Server
-	stopCh           chan struct{}
+	config            *config.Config
+	logger            *zap.Logger
+	kafka             *kafka.Client
+	debezium          *debezium.Manager
+	processorManager  *processors.ProcessorManager
+	errorReporter     errreport.Reporter
+	publisher         *publishing.Publisher
+	publishQueue      *publishing.Queue
+	schemaGuard       *schemas.Guard
+	publishACL        *acl.ACL
+	authenticator     *acl.Authenticator
+	eventStoreDB      *sql.DB
+	eventStore        *eventstore.PostgresStore
+	auditLog          *audit.PostgresStore
+	scheduledStore    *scheduler.PostgresStore
+	scheduler         *scheduler.Scheduler
+	catalogStore      *catalog.PostgresStore
+	catalog           *catalog.Catalog
+	projectionMetrics *projections.Metrics
+	httpServer        *http.Server
+	metricsServer     *http.Server
+	grpcServer        *grpc.Server
+	grpcHealth        *health.Server
+	reloader          *config.Reloader
+	status            *startupStatus
+	stopCh            chan struct{}
 }
 
 // EventBusHandler provides basic HTTP handlers for the Event Bus Service
@@ -41,6 +98,17 @@ type EventBusHandler struct {
 	kafka            *kafka.Client
 	debezium         *debezium.Manager
 	processorManager *processors.ProcessorManager
+	publisher        *publishing.Publisher
+	publishQueue     *publishing.Queue
+	reloader         *config.Reloader
+	events           eventstore.Searcher
+	audit            audit.Reader
+	scheduler        *scheduler.Scheduler
+	catalog          *catalog.Catalog
+	streams          *stream.Handler
+	schemas          *schemas.Guard
+	acl              *acl.ACL
+	auth             *acl.Authenticator
 }
 
 // APIResponse represents a standard API response
@@ -48,28 +116,85 @@ type APIResponse struct {
 	Success   bool        `json:"success"`
 	Message   string      `json:"message,omitempty"`
 	Data      interface{} `json:"data,omitempty"`
-	Error     interface{} `json:"error,omitempty"`
+	Error     interface{} `json:"error,omitempty" swaggertype:"string"`
 	Timestamp time.Time   `json:"timestamp"`
-	Version   string      `json:"version"`
+	Version   string      `json:"version" example:"1.0.0"`
+}
+
+// ErrorResponse is the APIResponse of a failed request. It documents every
+// 4xx and 5xx response of the API.
+type ErrorResponse struct {
+	Success   bool      `json:"success" example:"false"`
+	Message   string    `json:"message" example:"Invalid request body"`
+	Error     string    `json:"error,omitempty" example:"unexpected end of JSON input"`
+	Timestamp time.Time `json:"timestamp"`
+	Version   string    `json:"version" example:"1.0.0"`
+}
+
+// HealthStatus is the data of a health check
+type HealthStatus struct {
+	Status     string                     `json:"status" example:"healthy"`
+	Version    string                     `json:"version" example:"1.0.0"`
+	Timestamp  time.Time                  `json:"timestamp"`
+	Components map[string]ComponentHealth `json:"components"`
+}
+
+// ComponentHealth is the health of a dependency
+type ComponentHealth struct {
+	Status string `json:"status" example:"healthy"`
+	Error  string `json:"error,omitempty"`
+	// Details are the findings of the check, kafka.Health for Kafka
+	Details interface{} `json:"details,omitempty" swaggertype:"object"`
+}
+
+// VersionInfo is the data of a version request
+type VersionInfo struct {
+	Version   string `json:"version" example:"1.0.0"`
+	BuildTime string `json:"build_time"`
+	GitCommit string `json:"git_commit"`
+	GoVersion string `json:"go_version"`
+}
+
+// PublishedEvent is the data of a published event
+type PublishedEvent struct {
+	EventID string `json:"event_id"`
+	Topic   string `json:"topic"`
+	Status  string `json:"status" example:"published"`
+	// DeliverAt is the delivery time of scheduled events
+	DeliverAt *time.Time `json:"deliver_at,omitempty"`
 }
 
-// EventRequest represents an event publishing request
-type EventRequest struct {
-	EventType string                 `json:"event_type"`
-	Source    string                 `json:"source"`
-	Data      map[string]interface{} `json:"data"`
-	Topic     string                 `json:"topic"`
-	Key       string                 `json:"key"`
-	Headers   map[string]string      `json:"headers"`
+// route is an endpoint of the HTTP API. Handlers check the method
+// themselves; method is the one they accept. Endpoints under a subtree
+// pattern share its handler, which dispatches on the path.
+type route struct {
+	method  string
+	pattern string
+	handler http.HandlerFunc
 }
 
+//go:generate swag init --dir ./,../../internal/stream --generalInfo main.go --output ../../docs --parseDependency --parseInternal
+
 // main is the application entry point
+//
+// @title                      Event Bus Service API
+// @version                    1.0.0
+// @description                Event publishing, filtering and streaming over Kafka. Every JSON response is an APIResponse envelope; failures are documented as ErrorResponse.
+// @BasePath                   /
+// @securityDefinitions.apikey ApiKeyAuth
+// @in                         header
+// @name                       X-API-Key
+// @description                One of security.api_keys.keys. It may also be sent as "Authorization: Bearer <key>".
 func main() {
 	// Load configuration
-	cfg := config.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration:\n  - %s\n", strings.ReplaceAll(err.Error(), "\n", "\n  - "))
+		os.Exit(1)
+	}
 
 	// Initialize logger
-	logger, err := initLogger(cfg)
+	logger, logLevel, err := initLogger(cfg)
 	if err != nil {
 		fmt.Printf("Failed to initialize logger: %v\n", err)
 		os.Exit(1)
@@ -81,15 +206,35 @@ func main() {
 		zap.String("environment", "development"))
 
 	// Create application
-	app, err := NewApplication(cfg, logger)
+	app, err := NewApplication(cfg, logger, logLevel)
 	if err != nil {
 		logger.Fatal("Failed to create application", zap.Error(err))
 	}
 
-	// Start application
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Wait for Kafka and Debezium Connect, serving /health and /ready
+	// meanwhile. A shutdown signal ends the wait.
+	connectCtx, stopConnecting := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	err = app.Connect(connectCtx)
+	stopConnecting()
+	if err != nil {
+		app.Stop(ctx)
+		switch {
+		case errors.Is(err, startup.ErrUnavailable):
+			logger.Error("Dependencies unavailable at startup", zap.Error(err))
+			logger.Sync()
+			os.Exit(startup.ExitUnavailable)
+		case errors.Is(err, context.Canceled):
+			logger.Info("Shutdown signal received during startup")
+			return
+		default:
+			logger.Fatal("Failed to connect application", zap.Error(err))
+		}
+	}
+
+	// Start application
 	if err := app.Start(ctx); err != nil {
 		logger.Fatal("Failed to start application", zap.Error(err))
 	}
@@ -114,20 +259,18 @@ func main() {
 	logger.Info("Event Bus Service stopped")
 }
 
-// NewApplication creates a new application instance
-func NewApplication(cfg *config.Config, logger *zap.Logger) (*Application, error) {
+// NewApplication creates a new application instance. logLevel is the level
+// of logger, changed when the configuration is reloaded. It does not connect
+// to Kafka and Debezium Connect; Connect does.
+func NewApplication(cfg *config.Config, logger *zap.Logger, logLevel zap.AtomicLevel) (*Application, error) {
 	app := &Application{
-		config: cfg,
-		logger: logger,
-		stopCh: make(chan struct{}),
+		config:   cfg,
+		logger:   logger,
+		reloader: config.NewReloader(cfg, logger),
+		status:   newStartupStatus(&EventBusHandler{config: cfg, logger: logger}),
+		stopCh:   make(chan struct{}),
 	}
-
-	// Initialize Kafka client
-	kafkaClient, err := kafka.NewClient(cfg, logger)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Kafka client: %w", err)
-	}
-	app.kafka = kafkaClient
+	app.reloader.Register("logging", logLevelReloader{level: logLevel})
 
 	// Initialize Debezium manager
 	debeziumManager, err := debezium.NewManager(cfg, logger)
@@ -136,25 +279,148 @@ func NewApplication(cfg *config.Config, logger *zap.Logger) (*Application, error
 	}
 	app.debezium = debeziumManager
 
+	// Setup HTTP servers, which report the startup until Start opens the API
+	app.setupHTTPServers()
+
+	return app, nil
+}
+
+// Connect starts the HTTP servers, waits for Kafka and Debezium Connect to
+// be reachable, retrying with exponential backoff for server.startup.timeout,
+// and sets up the components using them. It returns an error wrapping
+// startup.ErrUnavailable when they are still unreachable at the deadline.
+func (app *Application) Connect(ctx context.Context) error {
+	cfg, logger := app.config, app.logger
+
+	if err := app.startHTTPServers(); err != nil {
+		return fmt.Errorf("failed to start HTTP servers: %w", err)
+	}
+
+	gate := startup.Gate{
+		Timeout:        cfg.Server.Startup.Timeout,
+		InitialBackoff: cfg.Server.Startup.InitialBackoff,
+		MaxBackoff:     cfg.Server.Startup.MaxBackoff,
+		Observe:        app.status.observe,
+	}
+	err := gate.WaitFor(ctx,
+		startup.Check{Name: "kafka", Run: func(ctx context.Context) error { return kafka.Ping(ctx, cfg) }},
+		startup.Check{Name: "debezium", Run: app.debezium.Ping},
+	)
+	if err != nil {
+		return err
+	}
+
+	// Initialize Kafka client
+	kafkaClient, err := kafka.NewClient(cfg, logger)
+	if err != nil {
+		return fmt.Errorf("failed to create Kafka client: %w", err)
+	}
+	app.kafka = kafkaClient
+
 	// Initialize processor manager
-	processorManager, err := processors.NewProcessorManager(cfg, logger, kafkaClient)
+	processorManager, err := processors.NewProcessorManager(cfg, logger, kafkaClient, processors.NewMetrics(prometheus.DefaultRegisterer))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create processor manager: %w", err)
+		return fmt.Errorf("failed to create processor manager: %w", err)
 	}
 	app.processorManager = processorManager
+	app.reloader.Register("processors", processorManager)
+	// Partitions of stopped processors are paused as they are assigned
+	processorManager.SetConsumer(kafkaClient)
 
-	// Setup HTTP servers
-	if err := app.setupHTTPServers(); err != nil {
-		return nil, fmt.Errorf("failed to setup HTTP servers: %w", err)
+	// Report the failures of consumed messages to the error tracker
+	errorReporter, err := telemetry.NewErrorReporter(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create error reporter: %w", err)
+	}
+	app.errorReporter = errorReporter
+	kafkaClient.SetErrorReporter(errorReporter)
+	processorManager.SetErrorReporter(errorReporter)
+
+	// Publishing path shared by the HTTP and gRPC APIs
+	app.publisher = publishing.NewPublisher(kafkaClient, publishing.NewMetrics(prometheus.DefaultRegisterer))
+	if registry := cfg.Kafka.SchemaRegistry; registry.Compatibility.Enabled {
+		app.schemaGuard = schemas.NewGuard(schemas.NewConfluentRegistry(registry, registry.Timeout),
+			registry.Compatibility, schemas.NewMetrics(prometheus.DefaultRegisterer), logger)
+		app.publisher.SetSchemaGuard(app.schemaGuard)
+	}
+	if cfg.Security.PublishACL.Enabled {
+		app.publishACL = acl.New(cfg.Security.PublishACL, acl.NewMetrics(prometheus.DefaultRegisterer), logger)
+		app.publishACL.SetAuditor(app.auditPublishDenied)
+		app.authenticator = acl.NewAuthenticator(cfg.Security)
+		app.publisher.SetAuthorizer(app.publishACL)
+		app.reloader.Register("publish_acl", app.publishACL)
+	}
+	if producer := cfg.Kafka.Producer; producer.PublishMode == "async" {
+		app.publishQueue = publishing.NewQueue(app.publisher, producer.AsyncQueueSize, producer.AsyncWorkers, retryAfter(cfg), logger)
 	}
 
-	return app, nil
+	// Event store database shared by the response and funnel projections,
+	// the event store, the privacy worker, the audit log, the scheduled
+	// events and the catalog
+	processing := cfg.EventProcessing
+	if processing.ResponseProjection.Enabled || processing.FunnelProjection.Enabled || processing.EventStore.Enabled || processing.Privacy.Enabled ||
+		processing.Audit.Enabled || processing.ScheduledEvents.Enabled || processing.Catalog.Enabled {
+		dbConfig := cfg.Databases.EventStore
+		db, err := sql.Open("postgres", dbConfig.GetConnectionString())
+		if err != nil {
+			return fmt.Errorf("failed to open event store database: %w", err)
+		}
+		db.SetMaxOpenConns(dbConfig.MaxOpenConns)
+		db.SetMaxIdleConns(dbConfig.MaxIdleConns)
+		db.SetConnMaxLifetime(dbConfig.ConnMaxLifetime)
+		app.eventStoreDB = db
+		app.projectionMetrics = projections.NewMetrics(prometheus.DefaultRegisterer)
+	}
+	if processing.EventStore.Enabled {
+		app.eventStore = eventstore.NewPostgresStore(app.eventStoreDB)
+	}
+	if processing.Audit.Enabled {
+		app.auditLog = audit.NewPostgresStore(app.eventStoreDB)
+	}
+	if processing.ScheduledEvents.Enabled {
+		app.scheduledStore = scheduler.NewPostgresStore(app.eventStoreDB)
+		app.scheduler = scheduler.NewScheduler(processing.ScheduledEvents, app.scheduledStore,
+			scheduler.NewPostgresElector(app.eventStoreDB), kafkaClient, scheduler.NewMetrics(prometheus.DefaultRegisterer), logger)
+	}
+	if processing.Catalog.Enabled {
+		// Schema versions are read through the cache of the schema guard
+		var versions catalog.SchemaVersions
+		if app.schemaGuard != nil {
+			versions = app.schemaGuard
+		}
+		app.catalogStore = catalog.NewPostgresStore(app.eventStoreDB)
+		app.catalog = catalog.New(processing.Catalog, app.catalogStore, versions, logger)
+		// The event types published and consumed are tracked from now on
+		kafkaClient.SetObserver(app.catalog.Tracker())
+	}
+
+	// Setup gRPC server
+	if cfg.Server.GRPC.Enabled {
+		app.grpcHealth = health.NewServer()
+		grpcService := grpcserver.NewServer(app.publisher, logger)
+		if app.authenticator != nil {
+			grpcService.SetAuthenticator(app.authenticator)
+		}
+		grpcServer, err := grpcserver.New(cfg.Server.GRPC, grpcService, app.grpcHealth, logger)
+		if err != nil {
+			return fmt.Errorf("failed to setup gRPC server: %w", err)
+		}
+		app.grpcServer = grpcServer
+	}
+
+	return nil
 }
 
-// Start starts the application and all its components
+// Start starts the application and all its components, once Connect has
+// connected it, and then serves the API and reports the service ready
 func (app *Application) Start(ctx context.Context) error {
 	app.logger.Info("Starting application components")
 
+	// Create and update the topics declared in the configuration
+	if err := app.kafka.ReconcileTopics(ctx); err != nil {
+		return fmt.Errorf("failed to provision Kafka topics: %w", err)
+	}
+
 	// Start Debezium manager
 	if err := app.debezium.Start(ctx); err != nil {
 		return fmt.Errorf("failed to start Debezium manager: %w", err)
@@ -165,11 +431,77 @@ func (app *Application) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to start processor manager: %w", err)
 	}
 
-	// Start HTTP servers
-	if err := app.startHTTPServers(); err != nil {
-		return fmt.Errorf("failed to start HTTP servers: %w", err)
+	// Start response projection
+	if err := app.startResponseProjection(ctx); err != nil {
+		return fmt.Errorf("failed to start response projection: %w", err)
+	}
+
+	// Start funnel projection
+	if err := app.startFunnelProjection(ctx); err != nil {
+		return fmt.Errorf("failed to start funnel projection: %w", err)
 	}
 
+	// Start notification worker
+	if err := app.startNotifier(ctx); err != nil {
+		return fmt.Errorf("failed to start notifier: %w", err)
+	}
+
+	// Start anomaly detector
+	if err := app.startAnomalyDetector(ctx); err != nil {
+		return fmt.Errorf("failed to start anomaly detector: %w", err)
+	}
+
+	// Start live stats publisher
+	if err := app.startLiveStats(ctx); err != nil {
+		return fmt.Errorf("failed to start live stats publisher: %w", err)
+	}
+
+	// Start cache invalidation bridge
+	if err := app.startCacheInvalidation(ctx); err != nil {
+		return fmt.Errorf("failed to start cache invalidation bridge: %w", err)
+	}
+
+	// Start event store
+	if err := app.startEventStore(ctx); err != nil {
+		return fmt.Errorf("failed to start event store: %w", err)
+	}
+
+	// Start privacy worker
+	if err := app.startPrivacy(ctx); err != nil {
+		return fmt.Errorf("failed to start privacy worker: %w", err)
+	}
+
+	// Start audit log
+	if err := app.startAudit(ctx); err != nil {
+		return fmt.Errorf("failed to start audit log: %w", err)
+	}
+
+	// Start scheduler
+	if err := app.startScheduler(ctx); err != nil {
+		return fmt.Errorf("failed to start scheduler: %w", err)
+	}
+
+	// Start event type catalog
+	if err := app.startCatalog(ctx); err != nil {
+		return fmt.Errorf("failed to start event type catalog: %w", err)
+	}
+
+	// Start draining the async publish queue
+	if app.publishQueue != nil {
+		app.publishQueue.Start()
+	}
+
+	// Start gRPC server
+	if err := app.startGRPCServer(); err != nil {
+		return fmt.Errorf("failed to start gRPC server: %w", err)
+	}
+
+	// Reload the configuration on SIGHUP and config file changes
+	go app.reloader.Watch(ctx)
+
+	// Serve the API and report ready
+	app.status.open(app.apiHandler())
+
 	return nil
 }
 
@@ -177,14 +509,31 @@ func (app *Application) Start(ctx context.Context) error {
 func (app *Application) Stop(ctx context.Context) error {
 	app.logger.Info("Stopping application components")
 
+	// Stop gRPC server
+	app.stopGRPCServer(ctx)
+
 	// Stop HTTP servers
 	if err := app.stopHTTPServers(ctx); err != nil {
 		app.logger.Error("Error stopping HTTP servers", zap.Error(err))
 	}
 
+	// Publish the events still queued
+	if app.publishQueue != nil {
+		if err := app.publishQueue.Stop(ctx); err != nil {
+			app.logger.Error("Error draining the publish queue", zap.Error(err))
+		}
+	}
+
 	// Stop processor manager
-	if err := app.processorManager.Stop(); err != nil {
-		app.logger.Error("Error stopping processor manager", zap.Error(err))
+	if app.processorManager != nil {
+		if err := app.processorManager.Stop(); err != nil {
+			app.logger.Error("Error stopping processor manager", zap.Error(err))
+		}
+	}
+
+	// Send the error events pending
+	if app.errorReporter != nil && !app.errorReporter.Flush(5*time.Second) {
+		app.logger.Warn("Timed out sending the pending error events")
 	}
 
 	// Stop Debezium manager
@@ -192,35 +541,326 @@ func (app *Application) Stop(ctx context.Context) error {
 		app.logger.Error("Error stopping Debezium manager", zap.Error(err))
 	}
 
-	// Close Kafka client
-	if err := app.kafka.Close(); err != nil {
-		app.logger.Error("Error closing Kafka client", zap.Error(err))
+	// Close Kafka client, unless stopped before Connect created it
+	if app.kafka != nil {
+		if err := app.kafka.Close(); err != nil {
+			app.logger.Error("Error closing Kafka client", zap.Error(err))
+		}
+	}
+
+	// Record the event types seen since the last flush
+	if app.catalog != nil {
+		app.catalog.Flush(ctx)
+	}
+
+	// Close event store database
+	if app.eventStoreDB != nil {
+		if err := app.eventStoreDB.Close(); err != nil {
+			app.logger.Error("Error closing event store database", zap.Error(err))
+		}
 	}
 
 	close(app.stopCh)
 	return nil
 }
 
-// setupHTTPServers sets up the HTTP servers for API and metrics
-func (app *Application) setupHTTPServers() error {
-	// Setup main API server
-	mux := http.NewServeMux()
+// startResponseProjection starts projecting form.response.created events into
+// the response_events table of the event store database
+func (app *Application) startResponseProjection(ctx context.Context) error {
+	cfg := app.config.EventProcessing.ResponseProjection
+	if !cfg.Enabled {
+		return nil
+	}
 
-	// Create handler
-	handler := &EventBusHandler{
-		config:           app.config,
-		logger:           app.logger,
-		kafka:            app.kafka,
-		debezium:         app.debezium,
-		processorManager: app.processorManager,
+	store := projections.NewPostgresStore(app.eventStoreDB)
+	if err := store.EnsureSchema(ctx); err != nil {
+		return err
 	}
 
-	// Register routes
-	handler.RegisterRoutes(mux)
+	projection := projections.NewResponseProjection(cfg, store, app.projectionMetrics, app.logger)
+	return app.kafka.StartBatchConsumer(ctx, projection, kafka.BatchOptions{
+		Size:          cfg.BatchSize,
+		FlushInterval: cfg.FlushInterval,
+		RetryBackoff:  app.config.EventProcessing.RetryBackoff,
+	})
+}
+
+// startFunnelProjection starts counting the funnel stages of sessions and
+// the submissions of forms into the form_funnel_daily table of the event
+// store database
+func (app *Application) startFunnelProjection(ctx context.Context) error {
+	cfg := app.config.EventProcessing.FunnelProjection
+	if !cfg.Enabled {
+		return nil
+	}
+
+	store := projections.NewPostgresStore(app.eventStoreDB)
+	if err := store.EnsureSchema(ctx); err != nil {
+		return err
+	}
+
+	projection := projections.NewFunnelProjection(cfg, store, app.projectionMetrics, app.logger)
+	return app.kafka.StartBatchConsumer(ctx, projection, kafka.BatchOptions{
+		Size:          cfg.BatchSize,
+		FlushInterval: cfg.FlushInterval,
+		RetryBackoff:  app.config.EventProcessing.RetryBackoff,
+	})
+}
+
+// startNotifier starts emailing form owners and respondents about form
+// events, and posting them to the chat channels of forms, as the
+// notification settings of each form ask
+func (app *Application) startNotifier(ctx context.Context) error {
+	cfg := app.config.EventProcessing.Notifications
+	if !cfg.Enabled {
+		return nil
+	}
+
+	sender, err := notifications.NewSender(cfg, app.logger)
+	if err != nil {
+		return err
+	}
+	forms := notifications.NewFormClient(cfg.FormServiceURL, cfg.SettingsCacheTTL)
+	// Chat cards show the responses of the day when they are projected
+	var counter notifications.ResponseCounter
+	if app.config.EventProcessing.ResponseProjection.Enabled {
+		counter = projections.NewPostgresStore(app.eventStoreDB)
+	}
+	notifier, err := notifications.New(cfg, forms, sender, app.kafka, counter, notifications.NewMetrics(prometheus.DefaultRegisterer), app.logger)
+	if err != nil {
+		return err
+	}
+
+	go notifier.RunDigests(ctx)
+	return app.kafka.StartBatchConsumer(ctx, notifier, kafka.BatchOptions{
+		Size:          cfg.BatchSize,
+		FlushInterval: cfg.FlushInterval,
+		RetryBackoff:  app.config.EventProcessing.RetryBackoff,
+	})
+}
+
+// startAnomalyDetector starts counting submissions and throttling the forms
+// whose submission rate surges
+func (app *Application) startAnomalyDetector(ctx context.Context) error {
+	cfg := app.config.EventProcessing.Anomaly
+	if !cfg.Enabled {
+		return nil
+	}
+
+	client, err := newRedisClient(app.config.Redis)
+	if err != nil {
+		return err
+	}
+	if err := client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	store := anomaly.NewRedisStore(client, cfg.KeyPrefix, anomaly.CountTTL(cfg), 2*cfg.BaselinePeriod)
+	forms := anomaly.NewFormClient(cfg.FormServiceURL, cfg.SettingsCacheTTL)
+	detector := anomaly.New(cfg, store, forms, anomaly.NewMetrics(prometheus.DefaultRegisterer), app.logger)
+
+	go detector.Run(ctx)
+	return app.kafka.StartBatchConsumer(ctx, detector, kafka.BatchOptions{
+		Size:          cfg.BatchSize,
+		FlushInterval: cfg.FlushInterval,
+		RetryBackoff:  app.config.EventProcessing.RetryBackoff,
+	})
+}
+
+// startLiveStats starts publishing the submissions of forms to the
+// dashboards of their owners
+func (app *Application) startLiveStats(ctx context.Context) error {
+	cfg := app.config.EventProcessing.LiveStats
+	if !cfg.Enabled {
+		return nil
+	}
+
+	client, err := newRedisClient(app.config.Redis)
+	if err != nil {
+		return err
+	}
+	if err := client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	counter := livestats.New(cfg, livestats.NewRedisPublisher(client), livestats.NewMetrics(prometheus.DefaultRegisterer), app.logger)
+	return app.kafka.StartBatchConsumer(ctx, counter, kafka.BatchOptions{
+		Size:          cfg.BatchSize,
+		FlushInterval: cfg.FlushInterval,
+		RetryBackoff:  app.config.EventProcessing.RetryBackoff,
+	})
+}
+
+// startCacheInvalidation starts bridging the changes of forms to the caches
+// of the gateways
+func (app *Application) startCacheInvalidation(ctx context.Context) error {
+	cfg := app.config.EventProcessing.CacheInvalidation
+	if !cfg.Enabled {
+		return nil
+	}
+
+	client, err := newRedisClient(app.config.Redis)
+	if err != nil {
+		return err
+	}
+	if err := client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	bridge := invalidation.New(cfg, livestats.NewRedisPublisher(client), invalidation.NewMetrics(prometheus.DefaultRegisterer), app.logger)
+	return app.kafka.StartBatchConsumer(ctx, bridge, kafka.BatchOptions{
+		Size:          cfg.BatchSize,
+		FlushInterval: cfg.FlushInterval,
+		RetryBackoff:  app.config.EventProcessing.RetryBackoff,
+	})
+}
+
+// newRedisClient creates a client of the configured Redis server
+func newRedisClient(cfg config.RedisConfig) (*redis.Client, error) {
+	options := &redis.Options{
+		Addr:         net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		Password:     cfg.Password,
+		DB:           cfg.DB,
+		PoolSize:     cfg.PoolSize,
+		DialTimeout:  cfg.DialTimeout,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+	}
+	if cfg.TLS.Enabled {
+		cert, err := tls.LoadX509KeyPair(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load Redis client certificate: %w", err)
+		}
+		options.TLSConfig = &tls.Config{
+			ServerName:   cfg.Host,
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		}
+	}
+	return redis.NewClient(options), nil
+}
+
+// startEventStore starts storing consumed events in the event_store table
+// of the event store database and purging them after the retention
+func (app *Application) startEventStore(ctx context.Context) error {
+	cfg := app.config.EventProcessing.EventStore
+	if !cfg.Enabled {
+		return nil
+	}
+
+	if err := app.eventStore.EnsureSchema(ctx); err != nil {
+		return err
+	}
+
+	// Without configured topics every topic is stored, except Kafka's own
+	if len(cfg.Topics) == 0 {
+		topics, err := app.kafka.ListTopics(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list topics to store: %w", err)
+		}
+		for _, topic := range topics {
+			if !strings.HasPrefix(topic, "__") {
+				cfg.Topics = append(cfg.Topics, topic)
+			}
+		}
+		sort.Strings(cfg.Topics)
+	}
+
+	go eventstore.RunRetention(ctx, app.eventStore, cfg.Retention, cfg.PurgeInterval, app.logger)
+
+	sink := eventstore.NewSink(cfg, app.eventStore, app.projectionMetrics, app.logger)
+	return app.kafka.StartBatchConsumer(ctx, sink, kafka.BatchOptions{
+		Size:          cfg.BatchSize,
+		FlushInterval: cfg.FlushInterval,
+		RetryBackoff:  app.config.EventProcessing.RetryBackoff,
+	})
+}
+
+// startPrivacy starts carrying out the erasure and export requests of the
+// gateway, and relaying the completions of the participants back to it
+func (app *Application) startPrivacy(ctx context.Context) error {
+	cfg := app.config.EventProcessing.Privacy
+	if !cfg.Enabled {
+		return nil
+	}
+
+	store := projections.NewPostgresStore(app.eventStoreDB)
+	if err := store.EnsureSchema(ctx); err != nil {
+		return err
+	}
+	services := privacy.NewServiceClient(map[string]string{
+		privacy.ParticipantFormService:          cfg.FormServiceURL,
+		privacy.ParticipantCollaborationService: cfg.CollaborationServiceURL,
+	}, cfg.ServiceToken, cfg.Timeout)
+
+	options := kafka.BatchOptions{
+		Size:          cfg.BatchSize,
+		FlushInterval: cfg.FlushInterval,
+		RetryBackoff:  app.config.EventProcessing.RetryBackoff,
+	}
+	worker := privacy.NewWorker(cfg, store, services, app.kafka, app.logger)
+	if err := app.kafka.StartBatchConsumer(ctx, worker, options); err != nil {
+		return err
+	}
+	return app.kafka.StartBatchConsumer(ctx, privacy.NewRelay(cfg, app.logger), options)
+}
+
+// startAudit starts appending the audit events of the services to the
+// audit_log table of the event store database
+func (app *Application) startAudit(ctx context.Context) error {
+	cfg := app.config.EventProcessing.Audit
+	if !cfg.Enabled {
+		return nil
+	}
+
+	if err := app.auditLog.EnsureSchema(ctx); err != nil {
+		return err
+	}
+
+	sink := audit.NewSink(cfg, app.auditLog, app.projectionMetrics, app.logger)
+	return app.kafka.StartBatchConsumer(ctx, sink, kafka.BatchOptions{
+		Size:          cfg.BatchSize,
+		FlushInterval: cfg.FlushInterval,
+		RetryBackoff:  app.config.EventProcessing.RetryBackoff,
+	})
+}
+
+// startScheduler starts delivering the scheduled events when due, while
+// this replica holds the scheduler lock
+func (app *Application) startScheduler(ctx context.Context) error {
+	if app.scheduler == nil {
+		return nil
+	}
+
+	if err := app.scheduledStore.EnsureSchema(ctx); err != nil {
+		return err
+	}
+	go app.scheduler.Run(ctx)
+	return nil
+}
+
+// startCatalog registers the services declared in the configuration with
+// the event type catalog, and starts recording the event types seen in
+// traffic
+func (app *Application) startCatalog(ctx context.Context) error {
+	if app.catalog == nil {
+		return nil
+	}
 
+	if err := app.catalogStore.EnsureSchema(ctx); err != nil {
+		return err
+	}
+	if err := app.catalog.RegisterConfigured(ctx); err != nil {
+		return err
+	}
+	go app.catalog.Run(ctx)
+	return nil
+}
+
+// setupHTTPServers sets up the HTTP servers for API and metrics
+func (app *Application) setupHTTPServers() {
+	// Setup main API server, whose handler is the startup status until the
+	// API is opened
 	app.httpServer = &http.Server{
-		Addr:         fmt.Sprintf("%s:%d", app.config.Server.Host, app.config.Server.Port),
-		Handler:      mux,
+		Addr:         net.JoinHostPort(app.config.Server.Host, app.config.Server.Port),
+		Handler:      app.status,
 		ReadTimeout:  app.config.Server.ReadTimeout,
 		WriteTimeout: app.config.Server.WriteTimeout,
 		IdleTimeout:  app.config.Server.IdleTimeout,
@@ -232,14 +872,54 @@ func (app *Application) setupHTTPServers() error {
 		metricsMux.Handle("/metrics", promhttp.Handler())
 
 		app.metricsServer = &http.Server{
-			Addr:         fmt.Sprintf(":%d", app.config.Observability.Metrics.Port),
+			Addr:         ":" + app.config.Observability.Metrics.Port,
 			Handler:      metricsMux,
 			ReadTimeout:  30 * time.Second,
 			WriteTimeout: 30 * time.Second,
 		}
 	}
+}
 
-	return nil
+// apiHandler creates the handler of the HTTP API
+func (app *Application) apiHandler() http.Handler {
+	mux := http.NewServeMux()
+
+	// Create handler
+	handler := &EventBusHandler{
+		config:           app.config,
+		logger:           app.logger,
+		kafka:            app.kafka,
+		debezium:         app.debezium,
+		processorManager: app.processorManager,
+		publisher:        app.publisher,
+		publishQueue:     app.publishQueue,
+		reloader:         app.reloader,
+		schemas:          app.schemaGuard,
+		acl:              app.publishACL,
+		auth:             app.authenticator,
+	}
+	if app.eventStore != nil {
+		handler.events = app.eventStore
+	}
+	if app.auditLog != nil {
+		handler.audit = app.auditLog
+	}
+	handler.scheduler = app.scheduler
+	handler.catalog = app.catalog
+	// Live event streams, ended when the server shuts down
+	if app.config.Server.Stream.Enabled {
+		handler.streams = stream.NewHandler(app.config, app.kafka, stream.NewMetrics(prometheus.DefaultRegisterer), app.logger)
+	}
+
+	// Register routes
+	handler.RegisterRoutes(mux)
+	// The spec is generated from the handler annotations with go generate
+	mux.Handle("/swagger/", httpSwagger.WrapHandler)
+
+	if handler.streams != nil {
+		app.httpServer.RegisterOnShutdown(handler.streams.Close)
+	}
+	return mux
 }
 
 // startHTTPServers starts the HTTP servers
@@ -294,22 +974,131 @@ func (app *Application) stopHTTPServers(ctx context.Context) error {
 	return lastErr
 }
 
+// startGRPCServer starts the gRPC server on its own port
+func (app *Application) startGRPCServer() error {
+	if app.grpcServer == nil {
+		return nil
+	}
+
+	addr := net.JoinHostPort(app.config.Server.GRPC.Host, app.config.Server.GRPC.Port)
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	go func() {
+		app.logger.Info("Starting gRPC server",
+			zap.String("address", addr),
+			zap.Bool("tls", app.config.Server.GRPC.TLS.Enabled))
+
+		if err := app.grpcServer.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			app.logger.Error("gRPC server error", zap.Error(err))
+		}
+	}()
+
+	return nil
+}
+
+// stopGRPCServer marks the server as not serving and drains in-flight calls
+// until ctx expires
+func (app *Application) stopGRPCServer(ctx context.Context) {
+	if app.grpcServer == nil {
+		return
+	}
+
+	app.logger.Info("Stopping gRPC server")
+	app.grpcHealth.Shutdown()
+
+	stopped := make(chan struct{})
+	go func() {
+		app.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		app.grpcServer.Stop()
+	}
+}
+
 // HTTP Handler Methods
 
 // RegisterRoutes registers all HTTP routes
 func (h *EventBusHandler) RegisterRoutes(mux *http.ServeMux) {
-	// Health and monitoring endpoints
-	mux.HandleFunc("/health", h.middleware(h.HealthCheck))
-	mux.HandleFunc("/version", h.middleware(h.GetVersion))
-
-	// Event publishing endpoints
-	mux.HandleFunc("/events", h.middleware(h.PublishEvent))
+	registered := make(map[string]bool)
+	for _, rt := range h.routes() {
+		if registered[rt.pattern] {
+			continue
+		}
+		registered[rt.pattern] = true
+		mux.HandleFunc(rt.pattern, h.middleware(rt.handler))
+	}
+}
 
-	// Admin endpoints
-	mux.HandleFunc("/admin/config", h.middleware(h.GetConfig))
+// routes lists the endpoints of the API. Each is documented by the swagger
+// annotations of its handler.
+func (h *EventBusHandler) routes() []route {
+	routes := []route{
+		// Health and monitoring endpoints
+		{http.MethodGet, "/health", h.HealthCheck},
+		{http.MethodGet, "/ready", h.Ready},
+		{http.MethodGet, "/version", h.GetVersion},
+
+		// Event publishing endpoints
+		{http.MethodPost, "/events", h.PublishEvent},
+		{http.MethodPost, "/events/filter", h.FilterEvents},
+		{http.MethodGet, scheduledEventsPath, h.ListScheduledEvents},
+		{http.MethodDelete, scheduledEventsPath + "/", h.CancelScheduledEvent},
+
+		// Topic endpoints
+		{http.MethodGet, "/topics/", h.GetTopic},
+
+		// Event type catalog endpoints
+		{http.MethodPost, catalogPath + "/register", h.RegisterCatalog},
+		{http.MethodGet, catalogPath + "/event-types", h.ListCatalogEventTypes},
+
+		// Schema endpoints
+		{http.MethodGet, schemasPath + "/", h.Schema},
+		{http.MethodPost, schemasPath + "/", h.Schema},
+
+		// Processor endpoints
+		{http.MethodGet, processorsPath, h.ListProcessors},
+		{http.MethodGet, processorsPath + "/", h.Processor},
+		{http.MethodPost, processorsPath + "/", h.Processor},
+
+		// Audit log endpoints
+		{http.MethodGet, "/audit", h.QueryAudit},
+		{http.MethodGet, "/audit/verify", h.VerifyAudit},
+
+		// Admin endpoints
+		{http.MethodGet, "/admin/config", h.GetConfig},
+		{http.MethodPost, "/admin/config/reload", h.ReloadConfig},
+		{http.MethodGet, "/admin/config/reload-status", h.GetReloadStatus},
+		{http.MethodGet, consumerGroupsPath, h.ListConsumerGroups},
+		{http.MethodGet, consumerGroupsPath + "/", h.ConsumerGroup},
+		{http.MethodPost, consumerGroupsPath + "/", h.ConsumerGroup},
+		{http.MethodGet, kafkaFailoverPath, h.GetKafkaFailover},
+		{http.MethodPost, kafkaFailoverPath + "/consumers", h.SwitchKafkaConsumers},
+		{http.MethodGet, "/admin/publish-acl", h.GetPublishACL},
+		{http.MethodGet, "/admin/topic-stats", h.GetTopicStats},
+	}
+	if h.streams != nil {
+		routes = append(routes, route{http.MethodGet, "/events/stream", h.streams.ServeHTTP})
+	}
+	return routes
 }
 
 // HealthCheck handles health check requests
+//
+// @Summary     Health check
+// @Description The details of the kafka component are a kafka.Health: the reachability of each broker, the partition leadership of kafka.health.critical_topics and the canary round trip. They are cached for kafka.health.cache_ttl. With kafka.failover, they are the health of the cluster the producer publishes to, and failover tells which clusters the producer and the consumers are on. While the service waits for Kafka and Debezium Connect at startup, the status is starting, with 200 so liveness probes do not restart it, and the components are the dependencies awaited.
+// @Tags        health
+// @Produce     json
+// @Success     200 {object} APIResponse{data=HealthStatus}
+// @Failure     503 {object} APIResponse{data=HealthStatus} "A dependency is unhealthy"
+// @Failure     405 {object} ErrorResponse
+// @Router      /health [get]
 func (h *EventBusHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
@@ -317,34 +1106,20 @@ func (h *EventBusHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check components
-	components := make(map[string]interface{})
-
-	// Check Kafka
-	kafkaHealthy := true
-	if err := h.kafka.HealthCheck(r.Context()); err != nil {
-		kafkaHealthy = false
-		components["kafka"] = map[string]interface{}{
-			"status": "unhealthy",
-			"error":  err.Error(),
-		}
-	} else {
-		components["kafka"] = map[string]interface{}{
-			"status": "healthy",
-		}
-	}
+	components := make(map[string]ComponentHealth)
+
+	// Check Kafka: brokers, critical topics and canary
+	kafkaHealth := h.kafka.Health(r.Context())
+	kafkaHealthy := kafkaHealth.Status == kafka.StatusHealthy
+	components["kafka"] = ComponentHealth{Status: kafkaHealth.Status, Error: kafkaHealth.Error, Details: kafkaHealth}
 
 	// Check Debezium
 	debeziumHealthy := true
 	if err := h.debezium.HealthCheck(r.Context()); err != nil {
 		debeziumHealthy = false
-		components["debezium"] = map[string]interface{}{
-			"status": "unhealthy",
-			"error":  err.Error(),
-		}
+		components["debezium"] = ComponentHealth{Status: "unhealthy", Error: err.Error()}
 	} else {
-		components["debezium"] = map[string]interface{}{
-			"status": "healthy",
-		}
+		components["debezium"] = ComponentHealth{Status: "healthy"}
 	}
 
 	// Overall status
@@ -355,98 +1130,388 @@ func (h *EventBusHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 		statusCode = http.StatusServiceUnavailable
 	}
 
-	response := map[string]interface{}{
-		"status":     overallStatus,
-		"version":    "1.0.0",
-		"timestamp":  time.Now(),
-		"components": components,
+	response := HealthStatus{
+		Status:     overallStatus,
+		Version:    "1.0.0",
+		Timestamp:  time.Now(),
+		Components: components,
 	}
 
 	h.respond(w, statusCode, overallStatus == "healthy", "Health check completed", response, nil)
 }
 
+// Ready handles readiness requests. The API, this route included, is only
+// served once the service has started; until then the startup status
+// answers them with 503.
+//
+// @Summary     Readiness check
+// @Description 503 while the service waits for Kafka and Debezium Connect at startup, with the status starting and the dependencies awaited as components; /health reports starting meanwhile, with 200. The wait is bounded by server.startup.timeout, after which the service exits with code 3.
+// @Tags        health
+// @Produce     json
+// @Success     200 {object} APIResponse{data=HealthStatus}
+// @Failure     503 {object} APIResponse{data=HealthStatus} "The service is starting"
+// @Failure     405 {object} ErrorResponse
+// @Router      /ready [get]
+func (h *EventBusHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
+		return
+	}
+
+	status := HealthStatus{Status: statusReady, Version: "1.0.0", Timestamp: time.Now(), Components: map[string]ComponentHealth{}}
+	h.respond(w, http.StatusOK, true, "Service is ready", status, nil)
+}
+
 // GetVersion handles version requests
+//
+// @Summary Service version
+// @Tags    health
+// @Produce json
+// @Success 200 {object} APIResponse{data=VersionInfo}
+// @Failure 405 {object} ErrorResponse
+// @Router  /version [get]
 func (h *EventBusHandler) GetVersion(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
 		return
 	}
 
-	version := map[string]interface{}{
-		"version":    "1.0.0",
-		"build_time": "2024-01-01T00:00:00Z",
-		"git_commit": "latest",
-		"go_version": "1.21",
+	version := VersionInfo{
+		Version:   "1.0.0",
+		BuildTime: "2024-01-01T00:00:00Z",
+		GitCommit: "latest",
+		GoVersion: "1.21",
 	}
 
 	h.respondSuccess(w, version, "Version information retrieved successfully")
 }
 
+// bodyBuffers holds the buffers publish request bodies are read into
+var bodyBuffers = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
 // PublishEvent handles event publishing
+//
+// @Summary     Publish an event
+// @Description The body is an EventRequest or a structured-mode CloudEvent. In the sync publish mode the event is published when the response is sent; in the async mode it is queued and the response is 202 with the status queued. With event_processing.scheduled_events, an EventRequest with a deliver_at is checked and stored, and the response is 202 with the status scheduled; it is published once due, at least once, and not ordered relative to the events published immediately. See GET /events/scheduled. 409 also means the ID of a scheduled event is taken, and 503 that scheduled delivery is not enabled. 429 means the messages waiting on the brokers, or the queue, are over their limits: retry after the Retry-After header. With kafka.schema_registry.compatibility, 409 means the data breaks the schema registered for the event type; the data lists the incompatible changes. With security.publish_acl, 403 means the caller, identified by its API key (X-API-Key or bearer), its JWT svc claim or its client certificate, may not publish to the topic; see GET /admin/publish-acl.
+// @Tags        events
+// @Accept      json
+// @Produce     json
+// @Param       event body     publishing.EventRequest true "Event to publish"
+// @Success     200   {object} APIResponse{data=PublishedEvent}
+// @Success     202   {object} APIResponse{data=PublishedEvent} "Queued, in the async publish mode, or scheduled"
+// @Failure     400   {object} ErrorResponse
+// @Failure     403   {object} ErrorResponse "The caller may not publish to the topic"
+// @Failure     405   {object} ErrorResponse
+// @Failure     409   {object} APIResponse{data=IncompatibleEvent} "The data is incompatible with the registered schema of the event type"
+// @Failure     413   {object} ErrorResponse
+// @Failure     429   {object} ErrorResponse
+// @Failure     500   {object} ErrorResponse
+// @Failure     503   {object} ErrorResponse "The service is shutting down, or scheduled delivery is not enabled"
+// @Router      /events [post]
 func (h *EventBusHandler) PublishEvent(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
 		return
 	}
 
-	var req EventRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	// The body is either an EventRequest or a structured-mode CloudEvent.
+	// The decoded request copies what it keeps of the body, so the buffer is
+	// reused once it is decoded.
+	body := bodyBuffers.Get().(*bytes.Buffer)
+	body.Reset()
+	defer bodyBuffers.Put(body)
+	if _, err := body.ReadFrom(r.Body); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	req, err := publishing.DecodeEventRequest(body.Bytes())
+	if err != nil {
 		h.respondError(w, http.StatusBadRequest, "Invalid request body", err)
 		return
 	}
 
-	// Validate request
-	if req.EventType == "" {
-		h.respondError(w, http.StatusBadRequest, "event_type is required", nil)
+	ctx := h.withCaller(r)
+	var result *publishing.Result
+	switch {
+	case req.DeliverAt != nil:
+		result, err = h.scheduleEvent(ctx, req)
+	case h.publishQueue != nil:
+		result, err = h.publishQueue.Enqueue(ctx, publishing.ProtocolHTTP, req)
+	default:
+		result, err = h.publisher.Publish(ctx, publishing.ProtocolHTTP, req)
+	}
+	if errors.Is(err, errSchedulingDisabled) {
+		h.respondError(w, http.StatusServiceUnavailable, "Scheduled delivery is not enabled", nil)
+		return
+	}
+	if errors.Is(err, scheduler.ErrDuplicate) {
+		h.respondError(w, http.StatusConflict, "An event with this ID is already scheduled", err)
+		return
+	}
+	if errors.Is(err, publishing.ErrInvalidEvent) {
+		h.respondError(w, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+	if errors.Is(err, acl.ErrForbidden) {
+		h.respondError(w, http.StatusForbidden, "Publishing to the topic is not allowed", err)
 		return
 	}
-	if req.Source == "" {
-		h.respondError(w, http.StatusBadRequest, "source is required", nil)
+	if errors.Is(err, kafka.ErrMessageTooLarge) {
+		h.respondError(w, http.StatusRequestEntityTooLarge, "Event too large", err)
 		return
 	}
-	if req.Data == nil {
-		h.respondError(w, http.StatusBadRequest, "data is required", nil)
+	var incompatible *schemas.IncompatibleError
+	if errors.As(err, &incompatible) {
+		h.respondIncompatible(w, incompatible)
+		return
+	}
+	if errors.Is(err, kafka.ErrBackpressure) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter(h.config).Seconds()))))
+		h.respondError(w, http.StatusTooManyRequests, "Too many events waiting to be published", err)
+		return
+	}
+	if errors.Is(err, publishing.ErrQueueClosed) {
+		h.respondError(w, http.StatusServiceUnavailable, "Service is shutting down", err)
+		return
+	}
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to publish event", err)
 		return
 	}
 
-	// Create message
-	message := &kafka.Message{
-		ID:        fmt.Sprintf("event_%d", time.Now().UnixNano()),
-		EventType: req.EventType,
-		Source:    req.Source,
-		Data:      req.Data,
-		Topic:     req.Topic,
-		Key:       req.Key,
-		Headers:   req.Headers,
-		Metadata: kafka.MessageMetadata{
-			Timestamp:   time.Now(),
-			Version:     "1.0",
-			ContentType: "application/json",
-			Encoding:    "utf-8",
-		},
+	published := PublishedEvent{
+		EventID: result.EventID,
+		Topic:   result.Topic,
+		Status:  result.Status,
+	}
+	if req.DeliverAt != nil {
+		published.DeliverAt = req.DeliverAt
+		h.respond(w, http.StatusAccepted, true, "Event scheduled for delivery", published, nil)
+		return
+	}
+	if h.publishQueue != nil {
+		h.respond(w, http.StatusAccepted, true, "Event queued for publishing", published, nil)
+		return
 	}
+	h.respondSuccess(w, published, "Event published successfully")
+}
 
-	if message.Headers == nil {
-		message.Headers = make(map[string]string)
+// FilterEvents returns a page of the stored events matching the filter of
+// the request body. The page is streamed inside the usual response envelope
+// as it is read from the event store.
+//
+// @Summary     Filter stored events
+// @Description Requires event_processing.event_store. limit defaults to 100 and is capped at 1000.
+// @Tags        events
+// @Accept      json
+// @Produce     json
+// @Param       filter body     eventstore.Filter true "Filter"
+// @Success     200    {object} APIResponse{data=eventstore.Page}
+// @Failure     400    {object} ErrorResponse
+// @Failure     405    {object} ErrorResponse
+// @Failure     500    {object} ErrorResponse
+// @Failure     503    {object} ErrorResponse "The event store is not enabled"
+// @Router      /events/filter [post]
+func (h *EventBusHandler) FilterEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
+		return
 	}
-	if message.Topic == "" {
-		message.Topic = fmt.Sprintf("app.%s", req.EventType)
+	if h.events == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "Event store is not enabled", nil)
+		return
 	}
 
-	// Publish message
-	if err := h.kafka.PublishMessage(r.Context(), message); err != nil {
-		h.respondError(w, http.StatusInternalServerError, "Failed to publish event", err)
+	var filter eventstore.Filter
+	if err := json.NewDecoder(r.Body).Decode(&filter); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	cursor, err := h.events.Search(r.Context(), filter)
+	if errors.Is(err, eventstore.ErrInvalidFilter) {
+		h.respondError(w, http.StatusBadRequest, "Invalid filter", err)
+		return
+	}
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to filter events", err)
+		return
+	}
+	defer cursor.Close()
+
+	// The status is sent before the first event is read, so a failure while
+	// streaming can only be logged
+	timestamp, _ := json.Marshal(time.Now())
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, `{"success":true,"message":"Events filtered successfully","data":`)
+	if _, err := cursor.WritePage(w); err != nil {
+		h.logger.Error("Failed to stream filtered events", zap.Error(err))
+		return
+	}
+	fmt.Fprintf(w, ",\"timestamp\":%s,\"version\":\"1.0.0\"}\n", timestamp)
+}
+
+// GetTopic returns the partitions and configuration of /topics/{name}
+//
+// @Summary Describe a topic
+// @Tags    topics
+// @Produce json
+// @Param   name path     string true "Topic name"
+// @Success 200  {object} APIResponse{data=kafka.TopicInfo}
+// @Failure 404  {object} ErrorResponse
+// @Failure 405  {object} ErrorResponse
+// @Failure 500  {object} ErrorResponse
+// @Router  /topics/{name} [get]
+func (h *EventBusHandler) GetTopic(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/topics/")
+	if name == "" || strings.Contains(name, "/") {
+		h.respondError(w, http.StatusNotFound, "Topic not found", nil)
+		return
+	}
+
+	info, err := h.kafka.DescribeTopic(r.Context(), name)
+	if errors.Is(err, kafka.ErrTopicNotFound) {
+		h.respondError(w, http.StatusNotFound, "Topic not found", err)
+		return
+	}
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to describe topic", err)
+		return
+	}
+	h.respondSuccess(w, info, "Topic information retrieved successfully")
+}
+
+// QueryAudit returns a page of the audit log, newest first
+//
+// @Summary     Query the audit log
+// @Description Requires event_processing.audit. resource is a resource type, or a type and ID as type:id. limit defaults to 50 and is capped at 500.
+// @Tags        audit
+// @Produce     json
+// @Param       actor    query    string false "Actor"
+// @Param       resource query    string false "Resource type, or type:id" example(form:7d9f)
+// @Param       from     query    string false "Earliest occurrence, RFC 3339" format(date-time)
+// @Param       to       query    string false "Occurrences before, RFC 3339" format(date-time)
+// @Param       limit    query    int    false "Page size"
+// @Param       before   query    string false "next_cursor of the previous page"
+// @Success     200      {object} APIResponse{data=audit.Page}
+// @Failure     400      {object} ErrorResponse
+// @Failure     405      {object} ErrorResponse
+// @Failure     500      {object} ErrorResponse
+// @Failure     503      {object} ErrorResponse "The audit log is not enabled"
+// @Router      /audit [get]
+func (h *EventBusHandler) QueryAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
+		return
+	}
+	if h.audit == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "Audit log is not enabled", nil)
+		return
+	}
+
+	filter, err := auditFilter(r.URL.Query())
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid filter", err)
+		return
+	}
+
+	page, err := h.audit.Query(r.Context(), filter)
+	if errors.Is(err, audit.ErrInvalidFilter) {
+		h.respondError(w, http.StatusBadRequest, "Invalid filter", err)
+		return
+	}
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to query the audit log", err)
+		return
+	}
+	h.respondSuccess(w, page, "Audit log retrieved successfully")
+}
+
+// auditFilter reads the audit filter of a query string
+func auditFilter(query url.Values) (audit.Filter, error) {
+	filter := audit.Filter{
+		Actor:    query.Get("actor"),
+		Resource: query.Get("resource"),
+	}
+	var err error
+	if filter.From, err = queryTime(query, "from"); err != nil {
+		return filter, err
+	}
+	if filter.To, err = queryTime(query, "to"); err != nil {
+		return filter, err
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			return filter, fmt.Errorf("limit must be a number")
+		}
+		filter.Limit = limit
+	}
+	if value := query.Get("before"); value != "" {
+		before, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("invalid cursor")
+		}
+		filter.Before = before
+	}
+	return filter, nil
+}
+
+// queryTime reads an optional RFC 3339 time of a query string
+func queryTime(query url.Values, name string) (*time.Time, error) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC 3339 time", name)
+	}
+	return &t, nil
+}
+
+// VerifyAudit walks the hash chain of the audit log and reports the first
+// broken link. It reads the whole log, so it is meant for occasional checks.
+//
+// @Summary Verify the audit log
+// @Tags    audit
+// @Produce json
+// @Success 200 {object} APIResponse{data=audit.Verification} "valid is false and first_broken set when the chain is broken"
+// @Failure 405 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse "The audit log is not enabled"
+// @Router  /audit/verify [get]
+func (h *EventBusHandler) VerifyAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
+		return
+	}
+	if h.audit == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "Audit log is not enabled", nil)
 		return
 	}
 
-	h.respondSuccess(w, map[string]interface{}{
-		"event_id": message.ID,
-		"topic":    message.Topic,
-		"status":   "published",
-	}, "Event published successfully")
+	verification, err := h.audit.Verify(r.Context())
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to verify the audit log", err)
+		return
+	}
+	h.respondSuccess(w, verification, "Audit log verified")
 }
 
 // GetConfig handles configuration requests
+//
+// @Summary Sanitized configuration
+// @Tags    admin
+// @Produce json
+// @Success 200 {object} APIResponse{data=object}
+// @Failure 405 {object} ErrorResponse
+// @Router  /admin/config [get]
 func (h *EventBusHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
@@ -454,23 +1519,81 @@ func (h *EventBusHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Return sanitized configuration (remove sensitive data)
+	cfg := h.reloader.Current()
 	sanitizedConfig := map[string]interface{}{
 		"server": map[string]interface{}{
-			"host": h.config.Server.Host,
-			"port": h.config.Server.Port,
+			"host": cfg.Server.Host,
+			"port": cfg.Server.Port,
 		},
 		"kafka": map[string]interface{}{
-			"brokers": h.config.Kafka.Brokers,
+			"brokers": cfg.Kafka.Brokers,
 		},
 		"event_processing": map[string]interface{}{
-			"workers":    h.config.EventProcessing.Workers,
-			"batch_size": h.config.EventProcessing.BatchSize,
+			"workers":    cfg.EventProcessing.Workers,
+			"batch_size": cfg.EventProcessing.BatchSize,
 		},
+		"log_level": cfg.Observability.Logging.Level,
 	}
 
 	h.respondSuccess(w, sanitizedConfig, "Configuration retrieved successfully")
 }
 
+// ReloadConfig re-reads the configuration, for environments where sending
+// SIGHUP is awkward
+//
+// @Summary Reload the configuration
+// @Tags    admin
+// @Produce json
+// @Success 200 {object} APIResponse{data=config.ReloadStatus}
+// @Failure 401 {object} ErrorResponse
+// @Failure 405 {object} ErrorResponse
+// @Failure 422 {object} APIResponse{data=config.ReloadStatus} "The new configuration is invalid"
+// @Router  /admin/config/reload [post]
+func (h *EventBusHandler) ReloadConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
+		return
+	}
+	actor, ok := h.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	status := h.reloader.Reload(config.ReloadTriggerAPI, actor)
+	if !status.Success {
+		h.respond(w, http.StatusUnprocessableEntity, false, "Configuration reload failed", status, status.Error)
+		return
+	}
+	h.respondSuccess(w, status, "Configuration reloaded")
+}
+
+// GetReloadStatus reports the outcome of the last configuration reload
+//
+// @Summary     Last configuration reload
+// @Description data is null when the configuration has not been reloaded since startup.
+// @Tags        admin
+// @Produce     json
+// @Success     200 {object} APIResponse{data=config.ReloadStatus}
+// @Failure     401 {object} ErrorResponse
+// @Failure     405 {object} ErrorResponse
+// @Router      /admin/config/reload-status [get]
+func (h *EventBusHandler) GetReloadStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
+		return
+	}
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+
+	status, ok := h.reloader.Status()
+	if !ok {
+		h.respondSuccess(w, nil, "Configuration has not been reloaded since startup")
+		return
+	}
+	h.respondSuccess(w, status, "Reload status retrieved successfully")
+}
+
 // Helper Methods
 
 // middleware wraps handlers with common middleware functionality
@@ -546,8 +1669,18 @@ func (h *EventBusHandler) respondError(w http.ResponseWriter, statusCode int, me
 
 // Utility Functions
 
-// initLogger initializes the logger based on configuration
-func initLogger(cfg *config.Config) (*zap.Logger, error) {
+// retryAfter is the delay clients refused for backpressure are asked to
+// wait before retrying
+func retryAfter(cfg *config.Config) time.Duration {
+	if delay := cfg.Kafka.Producer.Backpressure.RetryAfter; delay > 0 {
+		return delay
+	}
+	return time.Second
+}
+
+// initLogger initializes the logger based on configuration. The returned
+// level changes the level of the logger while it runs.
+func initLogger(cfg *config.Config) (*zap.Logger, zap.AtomicLevel, error) {
 	// Configure logger based on environment
 	var zapConfig zap.Config
 
@@ -577,8 +1710,22 @@ func initLogger(cfg *config.Config) (*zap.Logger, error) {
 
 	logger, err := zapConfig.Build()
 	if err != nil {
-		return nil, fmt.Errorf("failed to build logger: %w", err)
+		return nil, zapConfig.Level, fmt.Errorf("failed to build logger: %w", err)
 	}
 
-	return logger, nil
+	return logger, zapConfig.Level, nil
+}
+
+// logLevelReloader applies a reloaded log level to the running logger
+type logLevelReloader struct {
+	level zap.AtomicLevel
+}
+
+func (l logLevelReloader) ReloadConfig(cfg *config.Config) error {
+	level, err := zapcore.ParseLevel(cfg.Observability.Logging.Level)
+	if err != nil {
+		return err
+	}
+	l.level.SetLevel(level)
+	return nil
 }
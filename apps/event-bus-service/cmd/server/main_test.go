@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/docs"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/stream"
+)
+
+// specParam matches the {name} segments of swagger paths
+var specParam = regexp.MustCompile(`\{[^/]+\}`)
+
+// TestSwaggerSpecMatchesRoutes fails when a route is registered without being
+// documented, or documented without being registered. Regenerate the spec
+// with go generate after changing the routes or the handler annotations.
+func TestSwaggerSpecMatchesRoutes(t *testing.T) {
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal([]byte(docs.SwaggerInfo.ReadDoc()), &spec); err != nil {
+		t.Fatalf("generated spec is not valid JSON: %v", err)
+	}
+
+	cfg := &config.Config{Server: config.ServerConfig{Stream: config.StreamConfig{Enabled: true, MaxStreams: 1}}}
+	handler := &EventBusHandler{config: cfg, streams: stream.NewHandler(cfg, nil, nil, nil)}
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	registered := make(map[string]bool)
+	for _, rt := range handler.routes() {
+		registered[rt.method+" "+rt.pattern] = true
+	}
+
+	// Spec paths are resolved to the mux pattern serving them, which is how
+	// /topics/{name} maps to the /topics/ subtree. A subtree documents as
+	// many operations as it serves.
+	documented := make(map[string]bool)
+	var unregistered []string
+	for path, operations := range spec.Paths {
+		req := httptest.NewRequest(http.MethodGet, specParam.ReplaceAllString(path, "x"), nil)
+		_, pattern := mux.Handler(req)
+		for method := range operations {
+			operation := strings.ToUpper(method) + " " + pattern
+			if !registered[operation] {
+				unregistered = append(unregistered, strings.ToUpper(method)+" "+path)
+				continue
+			}
+			documented[operation] = true
+		}
+	}
+	sort.Strings(unregistered)
+
+	var undocumented []string
+	for operation := range registered {
+		if !documented[operation] {
+			undocumented = append(undocumented, operation)
+		}
+	}
+	sort.Strings(undocumented)
+
+	if len(undocumented) > 0 {
+		t.Errorf("routes missing from the swagger spec: %v", undocumented)
+	}
+	if len(unregistered) > 0 {
+		t.Errorf("swagger operations without a route: %v", unregistered)
+	}
+}
+
+func TestRequireAdmin(t *testing.T) {
+	cfg := &config.Config{Security: config.SecurityConfig{
+		AdminKeys: config.APIKeysConfig{Enabled: true, Keys: map[string]string{"ops": "s3cret"}},
+	}}
+	handler := &EventBusHandler{config: cfg, logger: zap.NewNop()}
+
+	tests := []struct {
+		name      string
+		header    string
+		value     string
+		wantActor string
+	}{
+		{"api key header", "X-API-Key", "s3cret", "ops"},
+		{"bearer token", "Authorization", "Bearer s3cret", "ops"},
+		{"wrong key", "X-API-Key", "guess", ""},
+		{"no key", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/consumer-groups", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+			actor, ok := handler.requireAdmin(rec, req)
+			if actor != tt.wantActor || ok != (tt.wantActor != "") {
+				t.Errorf("requireAdmin = %q, %v; want %q", actor, ok, tt.wantActor)
+			}
+			if !ok && rec.Code != http.StatusUnauthorized {
+				t.Errorf("status = %d, want 401", rec.Code)
+			}
+		})
+	}
+
+	// Disabled, no key is accepted
+	cfg.Security.AdminKeys.Enabled = false
+	req := httptest.NewRequest(http.MethodGet, "/admin/consumer-groups", nil)
+	req.Header.Set("X-API-Key", "s3cret")
+	if _, ok := handler.requireAdmin(httptest.NewRecorder(), req); ok {
+		t.Error("admin keys disabled: request authenticated")
+	}
+}
+
+func TestReloadConfigRequiresAdmin(t *testing.T) {
+	cfg := &config.Config{Security: config.SecurityConfig{
+		AdminKeys: config.APIKeysConfig{Enabled: true, Keys: map[string]string{"ops": "s3cret"}},
+	}}
+	handler := &EventBusHandler{config: cfg, logger: zap.NewNop(), reloader: config.NewReloader(cfg, zap.NewNop())}
+
+	for _, tt := range []struct {
+		method string
+		path   string
+		serve  http.HandlerFunc
+	}{
+		{http.MethodPost, "/admin/config/reload", handler.ReloadConfig},
+		{http.MethodGet, "/admin/config/reload-status", handler.GetReloadStatus},
+	} {
+		rec := httptest.NewRecorder()
+		tt.serve(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s without an admin key = %d, want 401", tt.method, tt.path, rec.Code)
+		}
+	}
+	if _, ok := handler.reloader.Status(); ok {
+		t.Error("configuration reloaded without an admin key")
+	}
+}
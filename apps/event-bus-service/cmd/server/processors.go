@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/processors"
+)
+
+// processorsPath prefixes the processor endpoints
+const processorsPath = "/processors"
+
+// ListProcessors reports the state and activity of the event processors
+//
+// @Summary     List processors
+// @Description The processors of event_processing.processors, sorted by name. healthy is false for processors that are not running or whose health check fails; last_error is the last error or recovered panic of the processor.
+// @Tags        processors
+// @Produce     json
+// @Success     200 {object} APIResponse{data=[]processors.ProcessorStatus}
+// @Failure     405 {object} ErrorResponse
+// @Router      /processors [get]
+func (h *EventBusHandler) ListProcessors(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
+		return
+	}
+	h.respondSuccess(w, h.processorManager.Statuses(), "Processors retrieved successfully")
+}
+
+// Processor serves the endpoints of a processor, under the /processors/
+// subtree
+func (h *EventBusHandler) Processor(w http.ResponseWriter, r *http.Request) {
+	name, action, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, processorsPath+"/"), "/")
+	if !ok || name == "" {
+		h.respondError(w, http.StatusNotFound, "Not found", nil)
+		return
+	}
+	switch action {
+	case "start":
+		h.StartProcessor(w, r, name)
+	case "stop":
+		h.StopProcessor(w, r, name)
+	case "assignment":
+		h.ProcessorAssignment(w, r, name)
+	default:
+		h.respondError(w, http.StatusNotFound, "Not found", nil)
+	}
+}
+
+// StartProcessor starts a stopped processor
+//
+// @Summary     Start a processor
+// @Description Requires one of security.admin_keys. Starts a processor that is stopped, disabled in the configuration or failed to start.
+// @Tags        processors
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       name path     string true "Processor name"
+// @Success     200  {object} APIResponse{data=processors.ProcessorStatus}
+// @Failure     401  {object} ErrorResponse
+// @Failure     404  {object} ErrorResponse
+// @Failure     405  {object} ErrorResponse
+// @Failure     409  {object} ErrorResponse "The processor is already running"
+// @Failure     500  {object} ErrorResponse "The processor failed to start"
+// @Router      /processors/{name}/start [post]
+func (h *EventBusHandler) StartProcessor(w http.ResponseWriter, r *http.Request, name string) {
+	h.changeProcessor(w, r, name, "start", h.processorManager.StartProcessor)
+}
+
+// StopProcessor stops a running processor
+//
+// @Summary     Stop a processor
+// @Description Requires one of security.admin_keys. The processor finishes the events it is processing and receives no more until started again.
+// @Tags        processors
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       name path     string true "Processor name"
+// @Success     200  {object} APIResponse{data=processors.ProcessorStatus}
+// @Failure     401  {object} ErrorResponse
+// @Failure     404  {object} ErrorResponse
+// @Failure     405  {object} ErrorResponse
+// @Failure     409  {object} ErrorResponse "The processor is not running"
+// @Failure     500  {object} ErrorResponse "The processor failed to stop"
+// @Router      /processors/{name}/stop [post]
+func (h *EventBusHandler) StopProcessor(w http.ResponseWriter, r *http.Request, name string) {
+	h.changeProcessor(w, r, name, "stop", h.processorManager.StopProcessor)
+}
+
+// ProcessorAssignment reports the partitions assigned to a processor
+//
+// @Summary     Get the assignment of a processor
+// @Description The partitions of the topics of the processor assigned to this replica in the consumer group kafka.consumer.group_id, with the static member ID of the replica. Partitions are paused while no running processor handles their topic. With a sticky rebalance strategy, partitions only move at rebalances when they must, and replicas restarting within the session timeout with their instance_id keep theirs.
+// @Tags        processors
+// @Produce     json
+// @Param       name path     string true "Processor name"
+// @Success     200  {object} APIResponse{data=processors.ProcessorAssignment}
+// @Failure     404  {object} ErrorResponse
+// @Failure     405  {object} ErrorResponse
+// @Router      /processors/{name}/assignment [get]
+func (h *EventBusHandler) ProcessorAssignment(w http.ResponseWriter, r *http.Request, name string) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
+		return
+	}
+	assignment, err := h.processorManager.Assignment(name)
+	if errors.Is(err, processors.ErrProcessorNotFound) {
+		h.respondError(w, http.StatusNotFound, "Processor not found", err)
+		return
+	}
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to get processor assignment", err)
+		return
+	}
+	h.respondSuccess(w, assignment, "Processor assignment retrieved successfully")
+}
+
+// changeProcessor starts or stops a processor with change and responds
+// with its status
+func (h *EventBusHandler) changeProcessor(w http.ResponseWriter, r *http.Request, name, action string, change func(ctx context.Context, name string) error) {
+	if r.Method != http.MethodPost {
+		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
+		return
+	}
+	actor, ok := h.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	err := change(r.Context(), name)
+	switch {
+	case errors.Is(err, processors.ErrProcessorNotFound):
+		h.respondError(w, http.StatusNotFound, "Processor not found", err)
+		return
+	case errors.Is(err, processors.ErrProcessorRunning), errors.Is(err, processors.ErrProcessorNotRunning):
+		h.respondError(w, http.StatusConflict, "Processor is "+h.processorState(name), err)
+		return
+	case err != nil:
+		h.respondError(w, http.StatusInternalServerError, "Failed to "+action+" processor", err)
+		return
+	}
+
+	h.logger.Info("Processor "+action+" requested",
+		zap.String("processor", name),
+		zap.String("actor", actor))
+	status, err := h.processorManager.Status(name)
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to get processor status", err)
+		return
+	}
+	h.respondSuccess(w, status, "Processor "+status.State)
+}
+
+// processorState returns the state of a processor, for error messages
+func (h *EventBusHandler) processorState(name string) string {
+	status, err := h.processorManager.Status(name)
+	if err != nil {
+		return "unknown"
+	}
+	return status.State
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/processors"
+)
+
+func TestProcessorEndpoints(t *testing.T) {
+	cfg := &config.Config{
+		Security: config.SecurityConfig{
+			AdminKeys: config.APIKeysConfig{Enabled: true, Keys: map[string]string{"ops": "s3cret"}},
+		},
+		EventProcessing: config.EventProcessingConfig{Processors: map[string]config.ProcessorConfig{
+			"form-processor":     {Type: "form", Enabled: true},
+			"response-processor": {Type: "response"},
+		}},
+	}
+	manager, err := processors.NewProcessorManager(cfg, nil, nil, processors.NewMetrics(prometheus.NewRegistry()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := &EventBusHandler{config: cfg, logger: zap.NewNop(), processorManager: manager}
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	tests := []struct {
+		method string
+		path   string
+		admin  bool
+		status int
+		state  string
+	}{
+		{http.MethodPost, "/processors/form-processor/stop", false, http.StatusUnauthorized, ""},
+		{http.MethodPost, "/processors/response-processor/start", true, http.StatusOK, processors.StateRunning},
+		{http.MethodPost, "/processors/response-processor/start", true, http.StatusConflict, ""},
+		{http.MethodPost, "/processors/response-processor/stop", true, http.StatusOK, processors.StateStopped},
+		{http.MethodPost, "/processors/response-processor/stop", true, http.StatusConflict, ""},
+		{http.MethodPost, "/processors/geo-processor/start", true, http.StatusNotFound, ""},
+		{http.MethodPost, "/processors/form-processor/pause", true, http.StatusNotFound, ""},
+		{http.MethodGet, "/processors/form-processor/start", true, http.StatusMethodNotAllowed, ""},
+		{http.MethodGet, "/processors/form-processor/assignment", false, http.StatusOK, ""},
+		{http.MethodGet, "/processors/geo-processor/assignment", false, http.StatusNotFound, ""},
+		{http.MethodPost, "/processors/form-processor/assignment", true, http.StatusMethodNotAllowed, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		if tt.admin {
+			req.Header.Set("X-API-Key", "s3cret")
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != tt.status {
+			t.Errorf("%s %s: status = %d, want %d", tt.method, tt.path, rec.Code, tt.status)
+			continue
+		}
+		if tt.state == "" {
+			continue
+		}
+		var resp struct {
+			Data processors.ProcessorStatus `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Data.State != tt.state {
+			t.Errorf("%s %s: state = %q (%v), want %s", tt.method, tt.path, resp.Data.State, err, tt.state)
+		}
+	}
+
+	// The list reports the state of every processor
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/processors", nil))
+	var list struct {
+		Data []processors.ProcessorStatus `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Data) != 2 || list.Data[0].Name != "form-processor" || list.Data[1].State != processors.StateStopped {
+		t.Errorf("processors = %+v, want form-processor and response-processor stopped", list.Data)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/acl"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/audit"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/publishing"
+)
+
+// GetPublishACL returns the publish ACL being enforced
+//
+// @Summary     Publish ACL
+// @Description Requires one of security.admin_keys. The ACL of security.publish_acl as enforced, reloads included. Callers are identified by principals: san:<name> for the SANs of their client certificate, api_key:<name> for their API key and svc:<name> for the svc claim of their JWT. An explicit deny of any rule matching the caller wins over allows; protected topics are denied unless a rule allows them; default_action decides the rest.
+// @Tags        admin
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Success     200 {object} APIResponse{data=config.PublishACLConfig}
+// @Failure     401 {object} ErrorResponse
+// @Failure     405 {object} ErrorResponse
+// @Router      /admin/publish-acl [get]
+func (h *EventBusHandler) GetPublishACL(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
+		return
+	}
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+
+	policy := h.config.Security.PublishACL
+	if h.acl != nil {
+		policy = h.acl.Effective()
+	}
+	h.respondSuccess(w, policy, "Publish ACL retrieved successfully")
+}
+
+// withCaller returns the context of r carrying its caller, for the publish
+// ACL
+func (h *EventBusHandler) withCaller(r *http.Request) context.Context {
+	if h.auth == nil {
+		return r.Context()
+	}
+	bearer, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return acl.NewContext(r.Context(), &acl.Caller{
+		Principals: h.auth.Principals(r.TLS, r.Header.Get("X-API-Key"), bearer),
+		IP:         ip,
+		RequestID:  r.Header.Get("X-Request-ID"),
+	})
+}
+
+// auditPublishDenied publishes the audit event of a publish the ACL denied.
+// The event is published by the service itself, so it is not subject to the
+// ACL.
+func (app *Application) auditPublishDenied(_ context.Context, caller *acl.Caller, decision acl.Decision) {
+	topics := app.config.EventProcessing.Audit.Topics
+	if len(topics) == 0 {
+		return
+	}
+
+	actor := "anonymous"
+	if len(caller.Principals) > 0 {
+		actor = caller.Principals[0]
+	}
+	_, err := app.publisher.Publish(context.Background(), publishing.ProtocolHTTP, &publishing.EventRequest{
+		EventType: audit.EventTypePrefix + "publish.denied",
+		Source:    "event-bus-service",
+		Subject:   decision.Topic,
+		Topic:     topics[0],
+		Key:       decision.Topic,
+		Data: map[string]interface{}{
+			"actor":          actor,
+			"resource_type":  "topic",
+			"resource_id":    decision.Topic,
+			"after":          map[string]interface{}{"principals": caller.Principals, "decision": decision},
+			"ip":             caller.IP,
+			"correlation_id": caller.RequestID,
+			"occurred_at":    time.Now().UTC(),
+		},
+	})
+	if err != nil {
+		app.logger.Error("Failed to publish the audit event of a denied publish",
+			zap.String("topic", decision.Topic), zap.Error(err))
+	}
+}
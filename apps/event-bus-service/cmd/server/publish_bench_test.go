@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/publishing"
+)
+
+// encodingProducer serializes messages as the Kafka client does before
+// handing them to the brokers. The broker round trip is left out of the
+// benchmarks.
+type encodingProducer struct{}
+
+func (encodingProducer) PublishMessage(ctx context.Context, message *kafka.Message) error {
+	_, _, err := kafka.EncodeMessage(message, kafka.EventFormatNative)
+	return err
+}
+
+// submissionBody is the body of a form.response.created publish carrying
+// answers answers of the usual question types
+func submissionBody(answers int) []byte {
+	values := make(map[string]interface{}, answers)
+	for i := 0; i < answers; i++ {
+		id := fmt.Sprintf("q-%03d", i)
+		switch i % 5 {
+		case 0:
+			values[id] = "A free text answer of a respondent, a sentence or two long."
+		case 1:
+			values[id] = i * 7
+		case 2:
+			values[id] = []string{"option-a", "option-c"}
+		case 3:
+			values[id] = map[string]interface{}{"file_id": "upl-" + id, "name": "receipt.pdf", "size": 48213}
+		default:
+			values[id] = i%2 == 0
+		}
+	}
+	body, _ := json.Marshal(map[string]interface{}{
+		"event_type": "form.response.created",
+		"source":     "response-service",
+		"subject":    "responses/r-1",
+		"key":        "f-1",
+		"data": map[string]interface{}{
+			"response_id":  "r-1",
+			"form_id":      "f-1",
+			"form_version": 3,
+			"revision":     1,
+			"answers_hash": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+			"answers":      values,
+			"respondent":   map[string]interface{}{"anonymous": true, "session_id": "s-1", "source": "embed"},
+			"submitted_at": "2026-10-01T12:00:00Z",
+		},
+	})
+	return body
+}
+
+// benchmarkResults is the checked-in output of
+//
+//	go test -run '^$' -bench BenchmarkPublishSubmission -benchmem -count=5 ./cmd/server
+//
+// It is regenerated when the publish path changes on purpose; the results
+// before the payload was kept raw are in publish_bench_before.txt, to compare
+// with benchstat.
+const benchmarkResults = "testdata/publish_bench.txt"
+
+// allocsTolerance is how much the allocations of a publish may grow over the
+// checked-in results before TestPublishSubmissionAllocations fails
+const allocsTolerance = 0.2
+
+// newPublishHandler serves the API with a publisher writing to an
+// encodingProducer
+func newPublishHandler() http.Handler {
+	handler := &EventBusHandler{
+		config:    &config.Config{},
+		logger:    zap.NewNop(),
+		publisher: publishing.NewPublisher(encodingProducer{}, publishing.NewMetrics(prometheus.NewRegistry())),
+	}
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	return mux
+}
+
+// BenchmarkPublishSubmission publishes a response submission through the
+// whole in-process path: the HTTP handler, validation and the serialization
+// of the Kafka message.
+func BenchmarkPublishSubmission(b *testing.B) {
+	for _, answers := range []int{10, 50, 200} {
+		b.Run(fmt.Sprintf("answers=%d", answers), func(b *testing.B) {
+			mux := newPublishHandler()
+			body := submissionBody(answers)
+			b.SetBytes(int64(len(body)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				rec := httptest.NewRecorder()
+				mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader(body)))
+				if rec.Code != http.StatusOK {
+					b.Fatalf("status %d: %s", rec.Code, rec.Body)
+				}
+			}
+		})
+	}
+}
+
+// TestPublishSubmissionAllocations fails when a publish allocates more than
+// the checked-in benchmark results allow, so a regression of the publish path
+// is caught without running the benchmarks
+func TestPublishSubmissionAllocations(t *testing.T) {
+	if raceEnabled {
+		t.Skip("sync.Pool drops items at random under the race detector")
+	}
+	baseline := readAllocs(t, benchmarkResults)
+	for _, answers := range []int{10, 50, 200} {
+		name := fmt.Sprintf("answers=%d", answers)
+		t.Run(name, func(t *testing.T) {
+			want, ok := baseline[name]
+			if !ok {
+				t.Fatalf("%s has no results for %s", benchmarkResults, name)
+			}
+			mux := newPublishHandler()
+			body := submissionBody(answers)
+			got := testing.AllocsPerRun(100, func() {
+				rec := httptest.NewRecorder()
+				mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader(body)))
+				if rec.Code != http.StatusOK {
+					t.Fatalf("status %d: %s", rec.Code, rec.Body)
+				}
+			})
+			if got > want*(1+allocsTolerance) {
+				t.Errorf("%.0f allocs/op, over %.0f%% more than the %.0f of %s", got, allocsTolerance*100, want, benchmarkResults)
+			}
+		})
+	}
+}
+
+// readAllocs returns the smallest allocs/op of each BenchmarkPublishSubmission
+// case of a benchmark output file
+func readAllocs(t *testing.T, path string) map[string]float64 {
+	t.Helper()
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocs := make(map[string]float64)
+	for _, line := range strings.Split(string(raw), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !strings.HasPrefix(fields[0], "BenchmarkPublishSubmission/") || fields[len(fields)-1] != "allocs/op" {
+			continue
+		}
+		name := strings.TrimPrefix(fields[0], "BenchmarkPublishSubmission/")
+		// Benchmark names end with -GOMAXPROCS when it isn't 1
+		if i := strings.LastIndex(name, "-"); i > 0 {
+			name = name[:i]
+		}
+		n, err := strconv.ParseFloat(fields[len(fields)-2], 64)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if current, ok := allocs[name]; !ok || n < current {
+			allocs[name] = n
+		}
+	}
+	return allocs
+}
@@ -0,0 +1,7 @@
+//go:build race
+
+package main
+
+// raceEnabled is true when the tests run with the race detector, which
+// changes the allocations measured
+const raceEnabled = true
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/acl"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/publishing"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/scheduler"
+)
+
+// scheduledEventsPath prefixes the scheduled event endpoints
+const scheduledEventsPath = "/events/scheduled"
+
+// errSchedulingDisabled is returned for events with a deliver_at when
+// event_processing.scheduled_events is disabled
+var errSchedulingDisabled = errors.New("scheduled delivery is not enabled")
+
+// scheduleEvent checks req as it would be published and stores it for
+// delivery at its deliver_at
+func (h *EventBusHandler) scheduleEvent(ctx context.Context, req *publishing.EventRequest) (*publishing.Result, error) {
+	if h.scheduler == nil {
+		return nil, errSchedulingDisabled
+	}
+	if err := h.publisher.Check(ctx, publishing.ProtocolHTTP, req); err != nil {
+		return nil, err
+	}
+	event, err := h.scheduler.Schedule(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &publishing.Result{EventID: event.ID, Topic: event.Topic, Status: "scheduled"}, nil
+}
+
+// ListScheduledEvents returns a page of the events pending delivery
+//
+// @Summary     List scheduled events
+// @Description Requires event_processing.scheduled_events. Lists the events published with a deliver_at and not yet delivered nor cancelled, by delivery time. from and to bound the delivery time. limit defaults to 100 and is capped at 1000.
+// @Tags        events
+// @Produce     json
+// @Param       topic      query    string false "Topic"
+// @Param       event_type query    string false "Event type"
+// @Param       source     query    string false "Source"
+// @Param       from       query    string false "Earliest delivery time, RFC 3339" format(date-time)
+// @Param       to         query    string false "Delivery times before, RFC 3339" format(date-time)
+// @Param       limit      query    int    false "Page size"
+// @Param       after      query    string false "next_cursor of the previous page"
+// @Success     200        {object} APIResponse{data=scheduler.Page}
+// @Failure     400        {object} ErrorResponse
+// @Failure     405        {object} ErrorResponse
+// @Failure     500        {object} ErrorResponse
+// @Failure     503        {object} ErrorResponse "Scheduled delivery is not enabled"
+// @Router      /events/scheduled [get]
+func (h *EventBusHandler) ListScheduledEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
+		return
+	}
+	if h.scheduler == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "Scheduled delivery is not enabled", nil)
+		return
+	}
+
+	filter, err := scheduledFilter(r.URL.Query())
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid filter", err)
+		return
+	}
+
+	page, err := h.scheduler.List(r.Context(), filter)
+	if errors.Is(err, scheduler.ErrInvalidFilter) {
+		h.respondError(w, http.StatusBadRequest, "Invalid filter", err)
+		return
+	}
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to list scheduled events", err)
+		return
+	}
+	h.respondSuccess(w, page, "Scheduled events retrieved successfully")
+}
+
+// scheduledFilter reads the scheduled event filter of a query string
+func scheduledFilter(query url.Values) (scheduler.Filter, error) {
+	filter := scheduler.Filter{
+		Topic:     query.Get("topic"),
+		EventType: query.Get("event_type"),
+		Source:    query.Get("source"),
+		After:     query.Get("after"),
+	}
+	var err error
+	if filter.From, err = queryTime(query, "from"); err != nil {
+		return filter, err
+	}
+	if filter.To, err = queryTime(query, "to"); err != nil {
+		return filter, err
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			return filter, fmt.Errorf("limit must be a number")
+		}
+		filter.Limit = limit
+	}
+	return filter, nil
+}
+
+// CancelScheduledEvent cancels /events/scheduled/{id}
+//
+// @Summary     Cancel a scheduled event
+// @Description Requires event_processing.scheduled_events. Only pending events can be cancelled: 409 means the event was delivered or cancelled already, or is being published. A cancelled event is never published. With security.publish_acl, 403 means the caller may not publish to the topic of the event.
+// @Tags        events
+// @Produce     json
+// @Param       id  path     string true "Event ID"
+// @Success     200 {object} APIResponse{data=scheduler.Event}
+// @Failure     403 {object} ErrorResponse "The caller may not publish to the topic of the event"
+// @Failure     404 {object} ErrorResponse
+// @Failure     405 {object} ErrorResponse
+// @Failure     409 {object} ErrorResponse "The event is no longer pending, or is being delivered"
+// @Failure     500 {object} ErrorResponse
+// @Failure     503 {object} ErrorResponse "Scheduled delivery is not enabled"
+// @Router      /events/scheduled/{id} [delete]
+func (h *EventBusHandler) CancelScheduledEvent(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, scheduledEventsPath+"/")
+	if id == "" || strings.Contains(id, "/") {
+		h.respondError(w, http.StatusNotFound, "Not found", nil)
+		return
+	}
+	if r.Method != http.MethodDelete {
+		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
+		return
+	}
+	if h.scheduler == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "Scheduled delivery is not enabled", nil)
+		return
+	}
+
+	ctx := h.withCaller(r)
+	if h.acl != nil {
+		event, err := h.scheduler.Get(ctx, id)
+		if errors.Is(err, scheduler.ErrNotFound) {
+			h.respondError(w, http.StatusNotFound, "Scheduled event not found", err)
+			return
+		}
+		if err != nil {
+			h.respondError(w, http.StatusInternalServerError, "Failed to cancel scheduled event", err)
+			return
+		}
+		if err := h.acl.Authorize(ctx, event.Topic); errors.Is(err, acl.ErrForbidden) {
+			h.respondError(w, http.StatusForbidden, "Cancelling events of the topic is not allowed", err)
+			return
+		}
+	}
+
+	event, err := h.scheduler.Cancel(ctx, id)
+	switch {
+	case errors.Is(err, scheduler.ErrNotFound):
+		h.respondError(w, http.StatusNotFound, "Scheduled event not found", err)
+	case errors.Is(err, scheduler.ErrNotPending), errors.Is(err, scheduler.ErrDelivering):
+		h.respondError(w, http.StatusConflict, "Scheduled event can't be cancelled", err)
+	case err != nil:
+		h.respondError(w, http.StatusInternalServerError, "Failed to cancel scheduled event", err)
+	default:
+		h.respondSuccess(w, event, "Scheduled event cancelled")
+	}
+}
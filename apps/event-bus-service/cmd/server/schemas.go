@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/schemas"
+)
+
+// schemasPath prefixes the schema endpoints
+const schemasPath = "/schemas"
+
+// SchemaCheckRequest is a schema to check, or a sample payload to infer it from
+type SchemaCheckRequest struct {
+	Schema *schemas.Schema        `json:"schema,omitempty"`
+	Sample map[string]interface{} `json:"sample,omitempty"`
+}
+
+// IncompatibleEvent is the data of a publish rejected by the schema guard
+type IncompatibleEvent struct {
+	EventType string `json:"event_type" example:"form.submitted"`
+	// Version is the registered version the event was checked against
+	Version int              `json:"version" example:"3"`
+	Changes []schemas.Change `json:"changes"`
+}
+
+// Schema serves the endpoints of the schemas of an event type, under the
+// /schemas/ subtree
+func (h *EventBusHandler) Schema(w http.ResponseWriter, r *http.Request) {
+	eventType, action, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, schemasPath+"/"), "/")
+	if !ok || eventType == "" {
+		h.respondError(w, http.StatusNotFound, "Not found", nil)
+		return
+	}
+	switch action {
+	case "versions":
+		h.SchemaVersions(w, r, eventType)
+	case "check":
+		h.CheckSchema(w, r, eventType)
+	default:
+		h.respondError(w, http.StatusNotFound, "Not found", nil)
+	}
+}
+
+// SchemaVersions lists the registered schema versions of an event type
+//
+// @Summary     List the schema versions of an event type
+// @Description Requires kafka.schema_registry.compatibility. The versions are read from the schema registry, oldest first.
+// @Tags        schemas
+// @Produce     json
+// @Param       event_type path     string true "Event type" example(form.submitted)
+// @Success     200        {object} APIResponse{data=[]schemas.Version}
+// @Failure     404        {object} ErrorResponse "No schema is registered for the event type"
+// @Failure     405        {object} ErrorResponse
+// @Failure     502        {object} ErrorResponse "The schema registry failed"
+// @Failure     503        {object} ErrorResponse "Schema compatibility is not enabled"
+// @Router      /schemas/{event_type}/versions [get]
+func (h *EventBusHandler) SchemaVersions(w http.ResponseWriter, r *http.Request, eventType string) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
+		return
+	}
+	if h.schemas == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "Schema compatibility is not enabled", nil)
+		return
+	}
+
+	versions, err := h.schemas.Versions(r.Context(), eventType)
+	if errors.Is(err, schemas.ErrSubjectNotFound) {
+		h.respondError(w, http.StatusNotFound, "No schema registered for the event type", err)
+		return
+	}
+	if err != nil {
+		h.respondError(w, http.StatusBadGateway, "Failed to read schema versions", err)
+		return
+	}
+	h.respondSuccess(w, versions, "Schema versions retrieved successfully")
+}
+
+// CheckSchema checks whether a schema can be published for an event type
+//
+// @Summary     Check a schema against the registered one
+// @Description Requires kafka.schema_registry.compatibility. The body holds a JSON schema, or a sample payload the schema is inferred from: fields present and not null are required, numbers without a fraction are integers. The schema is compatible when consumers of the latest registered version can read it: fields may be added, but required fields must not be removed or made optional and no field may change type. Event types without a registered schema are compatible. CI pipelines call it before deploying a producer.
+// @Tags        schemas
+// @Accept      json
+// @Produce     json
+// @Param       event_type path     string             true "Event type" example(form.submitted)
+// @Param       schema     body     SchemaCheckRequest true "Schema or sample payload"
+// @Success     200        {object} APIResponse{data=schemas.CheckResult}
+// @Failure     400        {object} ErrorResponse
+// @Failure     405        {object} ErrorResponse
+// @Failure     502        {object} ErrorResponse "The schema registry failed"
+// @Failure     503        {object} ErrorResponse "Schema compatibility is not enabled"
+// @Router      /schemas/{event_type}/check [post]
+func (h *EventBusHandler) CheckSchema(w http.ResponseWriter, r *http.Request, eventType string) {
+	if r.Method != http.MethodPost {
+		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
+		return
+	}
+	if h.schemas == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "Schema compatibility is not enabled", nil)
+		return
+	}
+
+	var req SchemaCheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	schema := req.Schema
+	switch {
+	case schema != nil && req.Sample != nil:
+		h.respondError(w, http.StatusBadRequest, "Either schema or sample is required, not both", nil)
+		return
+	case req.Sample != nil:
+		schema = schemas.Infer(req.Sample)
+	case schema == nil:
+		h.respondError(w, http.StatusBadRequest, "Either schema or sample is required", nil)
+		return
+	}
+
+	result, err := h.schemas.CheckSchema(r.Context(), eventType, schema)
+	if err != nil {
+		h.respondError(w, http.StatusBadGateway, "Failed to read the registered schema", err)
+		return
+	}
+	h.respondSuccess(w, result, "Schema checked successfully")
+}
+
+// respondIncompatible rejects an event refused by the schema guard with the
+// changes that broke compatibility
+func (h *EventBusHandler) respondIncompatible(w http.ResponseWriter, err *schemas.IncompatibleError) {
+	h.respond(w, http.StatusConflict, false, "Event is incompatible with the registered schema of its type",
+		IncompatibleEvent{EventType: err.EventType, Version: err.Version, Changes: err.Changes}, err.Error())
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Mir00r/X-Form-Backend/shared/startup"
+	"go.uber.org/zap"
+)
+
+// Statuses of the service while it starts
+const (
+	statusStarting = "starting"
+	statusReady    = "ready"
+)
+
+// startupStatus is the handler of the API server. Until the service has
+// connected to its dependencies and started, it serves /health, reporting
+// starting with the dependencies awaited, and /ready, with 503; every other
+// route is 503 as well. Once opened it serves the API.
+type startupStatus struct {
+	handler *EventBusHandler
+	started time.Time
+
+	mu         sync.RWMutex
+	api        http.Handler
+	components map[string]ComponentHealth
+}
+
+func newStartupStatus(handler *EventBusHandler) *startupStatus {
+	return &startupStatus{handler: handler, started: time.Now(), components: make(map[string]ComponentHealth)}
+}
+
+// observe records an attempt to connect to a dependency
+func (s *startupStatus) observe(attempt startup.Attempt) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if attempt.Err == nil {
+		s.components[attempt.Check] = ComponentHealth{Status: "healthy"}
+		s.handler.logger.Info("Dependency available",
+			zap.String("dependency", attempt.Check),
+			zap.Int("attempt", attempt.Number))
+		return
+	}
+	s.components[attempt.Check] = ComponentHealth{Status: "unavailable", Error: attempt.Err.Error()}
+	s.handler.logger.Warn("Dependency unavailable",
+		zap.String("dependency", attempt.Check),
+		zap.Int("attempt", attempt.Number),
+		zap.Duration("retry_in", attempt.Wait),
+		zap.Error(attempt.Err))
+}
+
+// open serves api from now on, which reports the service ready
+func (s *startupStatus) open(api http.Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.api = api
+	s.handler.logger.Info("Event Bus Service ready", zap.Duration("startup", time.Since(s.started)))
+}
+
+// ServeHTTP serves the API once opened, and the startup status until then
+func (s *startupStatus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	api := s.api
+	s.mu.RUnlock()
+	if api != nil {
+		api.ServeHTTP(w, r)
+		return
+	}
+	s.handler.middleware(s.serveStarting)(w, r)
+}
+
+func (s *startupStatus) serveStarting(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	components := make(map[string]ComponentHealth, len(s.components))
+	for name, component := range s.components {
+		components[name] = component
+	}
+	s.mu.RUnlock()
+	status := HealthStatus{Status: statusStarting, Version: "1.0.0", Timestamp: time.Now(), Components: components}
+
+	switch r.URL.Path {
+	case "/health":
+		// The process is alive: liveness probes must not restart it while
+		// it waits
+		s.handler.respond(w, http.StatusOK, true, "Service is starting", status, nil)
+	case "/ready":
+		s.handler.respond(w, http.StatusServiceUnavailable, false, "Service is starting", status, nil)
+	default:
+		w.Header().Set("Retry-After", "5")
+		s.handler.respond(w, http.StatusServiceUnavailable, false, "Service is starting", nil, "waiting for Kafka and Debezium Connect")
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/shared/startup"
+)
+
+func serveStatus(t *testing.T, handler http.Handler, path string) (int, HealthStatus) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	var response struct {
+		Data HealthStatus `json:"data"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &response)
+	return rec.Code, response.Data
+}
+
+func TestStartupStatus(t *testing.T) {
+	handler := &EventBusHandler{config: &config.Config{}, logger: zap.NewNop()}
+	status := newStartupStatus(handler)
+
+	status.observe(startup.Attempt{Check: "kafka", Number: 1})
+	status.observe(startup.Attempt{Check: "debezium", Number: 3, Err: errors.New("connection refused"), Wait: 2 * time.Second})
+
+	code, health := serveStatus(t, status, "/health")
+	if code != http.StatusOK || health.Status != statusStarting {
+		t.Errorf("/health = %d %q, want 200 starting", code, health.Status)
+	}
+	if c := health.Components["debezium"]; c.Status != "unavailable" || c.Error != "connection refused" {
+		t.Errorf("debezium = %+v, want unavailable with the error", c)
+	}
+	if c := health.Components["kafka"]; c.Status != "healthy" {
+		t.Errorf("kafka = %+v, want healthy", c)
+	}
+	if code, health := serveStatus(t, status, "/ready"); code != http.StatusServiceUnavailable || health.Status != statusStarting {
+		t.Errorf("/ready = %d %q, want 503 starting", code, health.Status)
+	}
+	rec := httptest.NewRecorder()
+	status.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/events", nil))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("POST /events = %d, Retry-After %q; want 503 with Retry-After", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	// Once open, the API answers, readiness included
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	status.open(mux)
+	if code, health := serveStatus(t, status, "/ready"); code != http.StatusOK || health.Status != statusReady {
+		t.Errorf("/ready once open = %d %q, want 200 ready", code, health.Status)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"net/http"
+)
+
+// GetTopicStats reports the volume of the topics for capacity planning
+//
+// @Summary     Topic stats
+// @Description Requires one of security.admin_keys and kafka.stats. For each topic this instance published or consumed messages of, their count since it started, their rate over kafka.stats.rate_window, the p50, p95 and p99 of their size, sampled into a reservoir of kafka.stats.reservoir_size, and their event types. Log sizes are read from the brokers every kafka.stats.log_size_interval for every topic: log_bytes is the size of a replica of its partitions and partition_skew the ratio of its largest partition to its smallest. Topics past kafka.stats.max_topics are counted under other.
+// @Tags        admin
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Success     200 {object} APIResponse{data=kafka.TopicStatsReport}
+// @Failure     401 {object} ErrorResponse
+// @Failure     405 {object} ErrorResponse
+// @Failure     503 {object} ErrorResponse "Topic stats are not enabled"
+// @Router      /admin/topic-stats [get]
+func (h *EventBusHandler) GetTopicStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
+		return
+	}
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+
+	report := h.kafka.TopicStats()
+	if report == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "Topic stats are not enabled", nil)
+		return
+	}
+	h.respondSuccess(w, report, "Topic stats retrieved successfully")
+}
@@ -0,0 +1,3294 @@
+// Package docs Code generated by swaggo/swag. DO NOT EDIT
+package docs
+
+import "github.com/swaggo/swag"
+
+const docTemplate = `{
+    "schemes": {{ marshal .Schemes }},
+    "swagger": "2.0",
+    "info": {
+        "description": "{{escape .Description}}",
+        "title": "{{.Title}}",
+        "contact": {},
+        "version": "{{.Version}}"
+    },
+    "host": "{{.Host}}",
+    "basePath": "{{.BasePath}}",
+    "paths": {
+        "/admin/config": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Sanitized configuration",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "object"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/config/reload": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Reload the configuration",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/config.ReloadStatus"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "The new configuration is invalid",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/config.ReloadStatus"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/config/reload-status": {
+            "get": {
+                "description": "data is null when the configuration has not been reloaded since startup.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Last configuration reload",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/config.ReloadStatus"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/consumer-groups": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Requires one of security.admin_keys. Groups are sorted by ID, with their members and the partitions assigned to them.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List consumer groups",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/kafka.ConsumerGroup"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/consumer-groups/{group}/offsets": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Requires one of security.admin_keys. Covers the partitions the group committed offsets for or is assigned. committed is -1 and lag null for partitions without a committed offset.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Consumer group offsets",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Consumer group ID",
+                        "name": "group",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/kafka.GroupOffsets"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/consumer-groups/{group}/reset": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Requires one of security.admin_keys. strategy is earliest, latest, timestamp or offsets. timestamp resets each partition to its first message at or after the time, or to its end when there is none; offsets sets the given offsets, clamped to the retained log. topics defaults to the topics the group committed offsets for. Groups with members are refused unless force is true, also accepted as ?force=true; even forced, Kafka refuses commits while members are joined. Every reset is recorded as an audit.consumer_group.offsets_reset event.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Reset consumer group offsets",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Consumer group ID",
+                        "name": "group",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Reset a group that has members",
+                        "name": "force",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Reset",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/kafka.OffsetReset"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/kafka.OffsetResetResult"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "The group or a topic does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "The group has members",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/kafka/failover": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Requires one of security.admin_keys and kafka.failover. The producer fails over to the secondary cluster by itself; the consumers stay where they are until switched with POST /admin/kafka/failover/consumers.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Kafka failover state",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/kafka.FailoverStatus"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Failover is not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/kafka/failover/consumers": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Requires one of security.admin_keys and kafka.failover. Consumers never fail over by themselves, as the offsets committed on one cluster do not apply to the other: switch them once the offsets of their groups are in place on the target. The consumer groups of the service rejoin on the target, and later stream subscriptions start there. The consumer group admin endpoints keep reading the primary. A failed switch is completed by switching again.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Switch the Kafka consumers",
+                "parameters": [
+                    {
+                        "description": "Target cluster",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.SwitchConsumersRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/kafka.FailoverStatus"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Some groups did not switch",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/kafka.FailoverStatus"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "503": {
+                        "description": "Failover is not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/publish-acl": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Requires one of security.admin_keys. The ACL of security.publish_acl as enforced, reloads included. Callers are identified by principals: san:\u003cname\u003e for the SANs of their client certificate, api_key:\u003cname\u003e for their API key and svc:\u003cname\u003e for the svc claim of their JWT. An explicit deny of any rule matching the caller wins over allows; protected topics are denied unless a rule allows them; default_action decides the rest.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Publish ACL",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/config.PublishACLConfig"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/topic-stats": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Requires one of security.admin_keys and kafka.stats. For each topic this instance published or consumed messages of, their count since it started, their rate over kafka.stats.rate_window, the p50, p95 and p99 of their size, sampled into a reservoir of kafka.stats.reservoir_size, and their event types. Log sizes are read from the brokers every kafka.stats.log_size_interval for every topic: log_bytes is the size of a replica of its partitions and partition_skew the ratio of its largest partition to its smallest. Topics past kafka.stats.max_topics are counted under other.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Topic stats",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/kafka.TopicStatsReport"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Topic stats are not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/audit": {
+            "get": {
+                "description": "Requires event_processing.audit. resource is a resource type, or a type and ID as type:id. limit defaults to 50 and is capped at 500.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "audit"
+                ],
+                "summary": "Query the audit log",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Actor",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "form:7d9f",
+                        "description": "Resource type, or type:id",
+                        "name": "resource",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Earliest occurrence, RFC 3339",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Occurrences before, RFC 3339",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "before",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/audit.Page"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "The audit log is not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/audit/verify": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "audit"
+                ],
+                "summary": "Verify the audit log",
+                "responses": {
+                    "200": {
+                        "description": "valid is false and first_broken set when the chain is broken",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/audit.Verification"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "The audit log is not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/catalog/event-types": {
+            "get": {
+                "description": "Requires event_processing.catalog. Lists every event type registered or seen in traffic, by name, with the services registered as its producers and consumers, the latest version of its schema in the schema registry (with kafka.schema_registry.compatibility), when an event of the type was last published or consumed by the service and a sample payload. Samples keep the shape of the data of a recent event, its values replaced by placeholders of their type. Stale registrations are flagged. The JSON is the contract of the developer portal: fields are only ever added.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "catalog"
+                ],
+                "summary": "List the event types of the catalog",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/catalog.Report"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "The catalog is not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/catalog/register": {
+            "post": {
+                "description": "Requires event_processing.catalog. Creates or replaces the registration of the service: the event types it produces and consumes, where the schemas of those it produces are and the team to contact. Registering again is the heartbeat of the service; registrations without a heartbeat nor traffic of their event types for event_processing.catalog.stale_after are flagged stale. Services register at startup, with the eventbus.CatalogClient of the shared client, or are declared in event_processing.catalog.registrations. With security.publish_acl, callers must present credentials.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "catalog"
+                ],
+                "summary": "Register with the event type catalog",
+                "parameters": [
+                    {
+                        "description": "Registration",
+                        "name": "registration",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CatalogRegistrationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/catalog.Registration"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "The catalog is not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/events": {
+            "post": {
+                "description": "The body is an EventRequest or a structured-mode CloudEvent. In the sync publish mode the event is published when the response is sent; in the async mode it is queued and the response is 202 with the status queued. With event_processing.scheduled_events, an EventRequest with a deliver_at is checked and stored, and the response is 202 with the status scheduled; it is published once due, at least once, and not ordered relative to the events published immediately. See GET /events/scheduled. 409 also means the ID of a scheduled event is taken, and 503 that scheduled delivery is not enabled. 429 means the messages waiting on the brokers, or the queue, are over their limits: retry after the Retry-After header. With kafka.schema_registry.compatibility, 409 means the data breaks the schema registered for the event type; the data lists the incompatible changes. With security.publish_acl, 403 means the caller, identified by its API key (X-API-Key or bearer), its JWT svc claim or its client certificate, may not publish to the topic; see GET /admin/publish-acl.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Publish an event",
+                "parameters": [
+                    {
+                        "description": "Event to publish",
+                        "name": "event",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/publishing.EventRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/main.PublishedEvent"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "202": {
+                        "description": "Queued, in the async publish mode, or scheduled",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/main.PublishedEvent"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "The caller may not publish to the topic",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "The data is incompatible with the registered schema of the event type",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/main.IncompatibleEvent"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "The service is shutting down, or scheduled delivery is not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/events/filter": {
+            "post": {
+                "description": "Requires event_processing.event_store. limit defaults to 100 and is capped at 1000.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Filter stored events",
+                "parameters": [
+                    {
+                        "description": "Filter",
+                        "name": "filter",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/eventstore.Filter"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/eventstore.Page"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "The event store is not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/events/scheduled": {
+            "get": {
+                "description": "Requires event_processing.scheduled_events. Lists the events published with a deliver_at and not yet delivered nor cancelled, by delivery time. from and to bound the delivery time. limit defaults to 100 and is capped at 1000.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "List scheduled events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Topic",
+                        "name": "topic",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Event type",
+                        "name": "event_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Source",
+                        "name": "source",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Earliest delivery time, RFC 3339",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Delivery times before, RFC 3339",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "after",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/scheduler.Page"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Scheduled delivery is not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/events/scheduled/{id}": {
+            "delete": {
+                "description": "Requires event_processing.scheduled_events. Only pending events can be cancelled: 409 means the event was delivered or cancelled already, or is being published. A cancelled event is never published. With security.publish_acl, 403 means the caller may not publish to the topic of the event.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Cancel a scheduled event",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/scheduler.Event"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "The caller may not publish to the topic of the event",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "The event is no longer pending, or is being delivered",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Scheduled delivery is not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/events/stream": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Server-Sent Events: each event is sent as an \"id:\" and a \"data:\" line holding the event JSON. Comments report heartbeats and events dropped by server.stream.rate_limit. Requires server.stream.enabled.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Stream live events",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Topic patterns",
+                        "name": "topics",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Event types to send",
+                        "name": "event_types",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Sources to send",
+                        "name": "sources",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Event stream",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No topic matches",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many open streams",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "The details of the kafka component are a kafka.Health: the reachability of each broker, the partition leadership of kafka.health.critical_topics and the canary round trip. They are cached for kafka.health.cache_ttl. With kafka.failover, they are the health of the cluster the producer publishes to, and failover tells which clusters the producer and the consumers are on. While the service waits for Kafka and Debezium Connect at startup, the status is starting, with 200 so liveness probes do not restart it, and the components are the dependencies awaited.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Health check",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/main.HealthStatus"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "A dependency is unhealthy",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/main.HealthStatus"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/processors": {
+            "get": {
+                "description": "The processors of event_processing.processors, sorted by name. healthy is false for processors that are not running or whose health check fails; last_error is the last error or recovered panic of the processor.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "processors"
+                ],
+                "summary": "List processors",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/processors.ProcessorStatus"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/processors/{name}/assignment": {
+            "get": {
+                "description": "The partitions of the topics of the processor assigned to this replica in the consumer group kafka.consumer.group_id, with the static member ID of the replica. Partitions are paused while no running processor handles their topic. With a sticky rebalance strategy, partitions only move at rebalances when they must, and replicas restarting within the session timeout with their instance_id keep theirs.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "processors"
+                ],
+                "summary": "Get the assignment of a processor",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Processor name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/processors.ProcessorAssignment"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/processors/{name}/start": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Requires one of security.admin_keys. Starts a processor that is stopped, disabled in the configuration or failed to start.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "processors"
+                ],
+                "summary": "Start a processor",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Processor name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/processors.ProcessorStatus"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "The processor is already running",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "The processor failed to start",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/processors/{name}/stop": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Requires one of security.admin_keys. The processor finishes the events it is processing and receives no more until started again.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "processors"
+                ],
+                "summary": "Stop a processor",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Processor name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/processors.ProcessorStatus"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "The processor is not running",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "The processor failed to stop",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/ready": {
+            "get": {
+                "description": "503 while the service waits for Kafka and Debezium Connect at startup, with the status starting and the dependencies awaited as components; /health reports starting meanwhile, with 200. The wait is bounded by server.startup.timeout, after which the service exits with code 3.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness check",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/main.HealthStatus"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "The service is starting",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/main.HealthStatus"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/schemas/{event_type}/check": {
+            "post": {
+                "description": "Requires kafka.schema_registry.compatibility. The body holds a JSON schema, or a sample payload the schema is inferred from: fields present and not null are required, numbers without a fraction are integers. The schema is compatible when consumers of the latest registered version can read it: fields may be added, but required fields must not be removed or made optional and no field may change type. Event types without a registered schema are compatible. CI pipelines call it before deploying a producer.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schemas"
+                ],
+                "summary": "Check a schema against the registered one",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "form.submitted",
+                        "description": "Event type",
+                        "name": "event_type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Schema or sample payload",
+                        "name": "schema",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.SchemaCheckRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/schemas.CheckResult"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "The schema registry failed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Schema compatibility is not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/schemas/{event_type}/versions": {
+            "get": {
+                "description": "Requires kafka.schema_registry.compatibility. The versions are read from the schema registry, oldest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schemas"
+                ],
+                "summary": "List the schema versions of an event type",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "form.submitted",
+                        "description": "Event type",
+                        "name": "event_type",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/schemas.Version"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "No schema is registered for the event type",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "The schema registry failed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Schema compatibility is not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/topics/{name}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "topics"
+                ],
+                "summary": "Describe a topic",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Topic name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/kafka.TopicInfo"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/version": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Service version",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/main.VersionInfo"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "audit.Break": {
+            "type": "object",
+            "properties": {
+                "event_id": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "hash does not match the entry"
+                },
+                "sequence": {
+                    "type": "integer"
+                }
+            }
+        },
+        "audit.Entry": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "form.published"
+                },
+                "actor": {
+                    "description": "Actor is the user or API key that acted",
+                    "type": "string"
+                },
+                "after": {
+                    "type": "object"
+                },
+                "before": {
+                    "description": "Before and After summarize the resource around the action",
+                    "type": "object"
+                },
+                "correlation_id": {
+                    "type": "string"
+                },
+                "event_id": {
+                    "type": "string"
+                },
+                "hash": {
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "occurred_at": {
+                    "type": "string"
+                },
+                "prev_hash": {
+                    "description": "PrevHash is the hash of the entry before, empty for the first",
+                    "type": "string"
+                },
+                "resource_id": {
+                    "type": "string"
+                },
+                "resource_type": {
+                    "type": "string",
+                    "example": "form"
+                },
+                "sequence": {
+                    "description": "Sequence numbers the entries from 1 without gaps",
+                    "type": "integer"
+                },
+                "source": {
+                    "type": "string"
+                }
+            }
+        },
+        "audit.Page": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/audit.Entry"
+                    }
+                },
+                "next_cursor": {
+                    "description": "NextCursor is passed as before for the following page, empty on the last",
+                    "type": "string"
+                }
+            }
+        },
+        "audit.Verification": {
+            "type": "object",
+            "properties": {
+                "checked": {
+                    "description": "Checked is the number of entries walked, up to the first broken link",
+                    "type": "integer"
+                },
+                "first_broken": {
+                    "description": "FirstBroken is the first entry that does not link up",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/audit.Break"
+                        }
+                    ]
+                },
+                "head_hash": {
+                    "type": "string"
+                },
+                "head_sequence": {
+                    "type": "integer"
+                },
+                "valid": {
+                    "type": "boolean"
+                },
+                "verified_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "catalog.EventType": {
+            "type": "object",
+            "properties": {
+                "consumers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/catalog.Party"
+                    }
+                },
+                "event_type": {
+                    "type": "string",
+                    "example": "form.published"
+                },
+                "last_seen": {
+                    "description": "LastSeen is when an event of the type was last published or\nconsumed, null until one is",
+                    "type": "string"
+                },
+                "producers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/catalog.Party"
+                    }
+                },
+                "sample_payload": {
+                    "description": "SamplePayload is the data of an event recently seen, null until one\nis",
+                    "type": "object"
+                },
+                "schema_version": {
+                    "description": "SchemaVersion is the latest version registered in the schema\nregistry, null without one or without schema compatibility",
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "catalog.Party": {
+            "type": "object",
+            "properties": {
+                "heartbeat_at": {
+                    "type": "string"
+                },
+                "schema_ref": {
+                    "type": "string"
+                },
+                "service": {
+                    "type": "string",
+                    "example": "form-service"
+                },
+                "stale": {
+                    "description": "Stale flags registrations without a heartbeat nor traffic of their\nevent types for event_processing.catalog.stale_after",
+                    "type": "boolean"
+                },
+                "team": {
+                    "type": "string",
+                    "example": "forms"
+                }
+            }
+        },
+        "catalog.Registration": {
+            "type": "object",
+            "properties": {
+                "consumes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "form.response.created"
+                    ]
+                },
+                "heartbeat_at": {
+                    "type": "string"
+                },
+                "produces": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "form.published"
+                    ]
+                },
+                "registered_at": {
+                    "type": "string"
+                },
+                "schema_ref": {
+                    "description": "SchemaRef locates the schemas of the events the service produces",
+                    "type": "string",
+                    "example": "https://github.com/Mir00r/X-Form-Backend/tree/main/schemas/form-service"
+                },
+                "service": {
+                    "type": "string",
+                    "example": "form-service"
+                },
+                "source": {
+                    "description": "Source is api for services registered through the API, config for\nthose declared in event_processing.catalog.registrations",
+                    "type": "string",
+                    "example": "api"
+                },
+                "team": {
+                    "type": "string",
+                    "example": "forms"
+                }
+            }
+        },
+        "catalog.Report": {
+            "type": "object",
+            "properties": {
+                "event_types": {
+                    "description": "EventTypes are sorted by name",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/catalog.EventType"
+                    }
+                },
+                "generated_at": {
+                    "type": "string"
+                },
+                "stale_after": {
+                    "description": "StaleAfter is how long registrations go without a heartbeat nor\ntraffic before they are flagged stale",
+                    "type": "string",
+                    "example": "720h0m0s"
+                }
+            }
+        },
+        "config.PublishACLConfig": {
+            "type": "object",
+            "properties": {
+                "default_action": {
+                    "description": "DefaultAction applies to the topics no rule of the caller covers:\nallow or deny",
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "protected_topics": {
+                    "description": "ProtectedTopics are patterns of topics denied to callers no rule\nexplicitly allows, whatever the default action",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "rules": {
+                    "description": "Rules are evaluated together: a deny of any rule matching the caller\nwins over the allows",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/config.PublishACLRule"
+                    }
+                }
+            }
+        },
+        "config.PublishACLRule": {
+            "type": "object",
+            "properties": {
+                "allow": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "form.response.*"
+                    ]
+                },
+                "deny": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "principal": {
+                    "description": "Principal is kind:name, where a name of * matches any of the kind",
+                    "type": "string",
+                    "example": "svc:response-service"
+                }
+            }
+        },
+        "config.ReloadStatus": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "description": "admin key of an api reload",
+                    "type": "string"
+                },
+                "applied": {
+                    "description": "Applied lists the settings changed live",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "restart_required": {
+                    "description": "RestartRequired lists changed settings that only take effect on restart",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "success": {
+                    "type": "boolean"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "trigger": {
+                    "type": "string"
+                }
+            }
+        },
+        "events.FilterCondition": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "operator": {
+                    "description": "eq, ne, gt, lt, gte, lte, in, nin, regex",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "string, number, boolean, date",
+                    "type": "string"
+                },
+                "value": {}
+            }
+        },
+        "events.TimeRange": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "eventstore.Filter": {
+            "type": "object",
+            "properties": {
+                "conditions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/events.FilterCondition"
+                    }
+                },
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "exclude_fields": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "include_fields": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "operations": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "sources": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tables": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "time_range": {
+                    "$ref": "#/definitions/events.TimeRange"
+                }
+            }
+        },
+        "eventstore.Page": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "object",
+                        "additionalProperties": true
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "next_offset": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                }
+            }
+        },
+        "kafka.ConsumerGroup": {
+            "type": "object",
+            "properties": {
+                "group_id": {
+                    "type": "string"
+                },
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kafka.GroupMember"
+                    }
+                },
+                "protocol_type": {
+                    "type": "string",
+                    "example": "consumer"
+                },
+                "state": {
+                    "type": "string",
+                    "example": "Stable"
+                }
+            }
+        },
+        "kafka.FailoverStatus": {
+            "type": "object",
+            "properties": {
+                "consumer_cluster": {
+                    "description": "ConsumerCluster is the cluster consumer groups read from, only\nswitched by hand",
+                    "type": "string",
+                    "example": "primary"
+                },
+                "last_failback": {
+                    "type": "string"
+                },
+                "last_failover": {
+                    "type": "string"
+                },
+                "primary_status": {
+                    "type": "string",
+                    "example": "healthy"
+                },
+                "primary_unhealthy_since": {
+                    "type": "string"
+                },
+                "producer_cluster": {
+                    "description": "ProducerCluster is the cluster messages are published to",
+                    "type": "string",
+                    "example": "primary"
+                },
+                "secondary_status": {
+                    "type": "string",
+                    "example": "healthy"
+                },
+                "switching": {
+                    "description": "Switching is set while the primary is unhealthy within the grace\nperiod, when publishes are buffered or refused",
+                    "type": "boolean"
+                }
+            }
+        },
+        "kafka.GroupMember": {
+            "type": "object",
+            "properties": {
+                "assignment": {
+                    "description": "Assignment maps topics to the partitions assigned to the member",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "type": "integer",
+                            "format": "int32"
+                        }
+                    }
+                },
+                "client_host": {
+                    "type": "string"
+                },
+                "client_id": {
+                    "type": "string"
+                },
+                "instance_id": {
+                    "description": "InstanceID is the static membership ID of the member, if any",
+                    "type": "string"
+                },
+                "member_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "kafka.GroupOffsets": {
+            "type": "object",
+            "properties": {
+                "group_id": {
+                    "type": "string"
+                },
+                "partitions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kafka.PartitionOffset"
+                    }
+                },
+                "total_lag": {
+                    "description": "TotalLag sums the lag of the partitions with a committed offset",
+                    "type": "integer"
+                }
+            }
+        },
+        "kafka.MessageStats": {
+            "type": "object",
+            "properties": {
+                "event_types": {
+                    "description": "EventTypes counts the messages by event type, the types past the\nfirst 20 under other",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer",
+                        "format": "int64"
+                    }
+                },
+                "messages": {
+                    "description": "Messages counts the messages since the service started",
+                    "type": "integer"
+                },
+                "rate": {
+                    "description": "Rate is in messages per second over the rate window",
+                    "type": "number"
+                },
+                "size": {
+                    "description": "Size is null without messages",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/kafka.SizePercentiles"
+                        }
+                    ]
+                }
+            }
+        },
+        "kafka.OffsetReset": {
+            "type": "object",
+            "properties": {
+                "force": {
+                    "description": "Force resets groups with active members. Kafka rejects offsets\ncommitted for members still in the group, so it is meant for members\nthat left without the coordinator noticing yet.",
+                    "type": "boolean"
+                },
+                "offsets": {
+                    "description": "Offsets maps topics to partitions to the offsets the offsets strategy\nresets to. Offsets outside the retained messages are moved to the\nnearest one.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "object",
+                        "additionalProperties": {
+                            "type": "integer",
+                            "format": "int64"
+                        }
+                    }
+                },
+                "strategy": {
+                    "type": "string",
+                    "enum": [
+                        "earliest",
+                        "latest",
+                        "timestamp",
+                        "offsets"
+                    ]
+                },
+                "timestamp": {
+                    "description": "Timestamp is where the timestamp strategy resets to",
+                    "type": "string"
+                },
+                "topics": {
+                    "description": "Topics are the topics reset by the earliest, latest and timestamp\nstrategies, every partition of each. They default to the topics the\ngroup committed offsets on.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "kafka.OffsetResetResult": {
+            "type": "object",
+            "properties": {
+                "forced": {
+                    "type": "boolean"
+                },
+                "group_id": {
+                    "type": "string"
+                },
+                "partitions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kafka.PartitionReset"
+                    }
+                },
+                "strategy": {
+                    "type": "string"
+                }
+            }
+        },
+        "kafka.PartitionInfo": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "in_sync_replicas": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "leader": {
+                    "type": "integer"
+                },
+                "offline_replicas": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "replicas": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "kafka.PartitionOffset": {
+            "type": "object",
+            "properties": {
+                "committed": {
+                    "description": "Committed is the offset of the next message the group consumes, -1\nwhen it committed none",
+                    "type": "integer"
+                },
+                "end": {
+                    "description": "End is the offset the next message published will get",
+                    "type": "integer"
+                },
+                "lag": {
+                    "description": "Lag is the number of messages left to consume, null without a\ncommitted offset",
+                    "type": "integer"
+                },
+                "partition": {
+                    "type": "integer"
+                },
+                "topic": {
+                    "type": "string"
+                }
+            }
+        },
+        "kafka.PartitionReset": {
+            "type": "object",
+            "properties": {
+                "offset": {
+                    "type": "integer"
+                },
+                "partition": {
+                    "type": "integer"
+                },
+                "previous": {
+                    "type": "integer"
+                },
+                "topic": {
+                    "type": "string"
+                }
+            }
+        },
+        "kafka.SizePercentiles": {
+            "type": "object",
+            "properties": {
+                "p50": {
+                    "type": "integer"
+                },
+                "p95": {
+                    "type": "integer"
+                },
+                "p99": {
+                    "type": "integer"
+                },
+                "samples": {
+                    "type": "integer"
+                }
+            }
+        },
+        "kafka.TopicInfo": {
+            "type": "object",
+            "properties": {
+                "config": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "internal": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "partitions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kafka.PartitionInfo"
+                    }
+                },
+                "replication_factor": {
+                    "type": "integer"
+                }
+            }
+        },
+        "kafka.TopicStats": {
+            "type": "object",
+            "properties": {
+                "consumed": {
+                    "$ref": "#/definitions/kafka.MessageStats"
+                },
+                "log_bytes": {
+                    "description": "LogBytes is the size of a replica of the partitions of the topic,\nnull until log sizes are read",
+                    "type": "integer"
+                },
+                "partition_skew": {
+                    "description": "PartitionSkew is the ratio of the largest partition to the smallest,\nnull when a partition is empty",
+                    "type": "number"
+                },
+                "partitions": {
+                    "type": "integer"
+                },
+                "published": {
+                    "$ref": "#/definitions/kafka.MessageStats"
+                },
+                "topic": {
+                    "type": "string",
+                    "example": "app.form.response.created"
+                }
+            }
+        },
+        "kafka.TopicStatsReport": {
+            "type": "object",
+            "properties": {
+                "log_sizes_at": {
+                    "description": "LogSizesAt is when the log sizes were read, null until they are",
+                    "type": "string"
+                },
+                "rate_window": {
+                    "description": "RateWindow is the window rates are measured over",
+                    "type": "string",
+                    "example": "1m0s"
+                },
+                "topics": {
+                    "description": "Topics are sorted by rate, busiest first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kafka.TopicStats"
+                    }
+                }
+            }
+        },
+        "main.APIResponse": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "error": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                },
+                "timestamp": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string",
+                    "example": "1.0.0"
+                }
+            }
+        },
+        "main.CatalogRegistrationRequest": {
+            "type": "object",
+            "properties": {
+                "consumes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "form.response.created"
+                    ]
+                },
+                "produces": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "form.published"
+                    ]
+                },
+                "schema_ref": {
+                    "type": "string",
+                    "example": "https://github.com/Mir00r/X-Form-Backend/tree/main/schemas/form-service"
+                },
+                "service": {
+                    "type": "string",
+                    "example": "form-service"
+                },
+                "team": {
+                    "type": "string",
+                    "example": "forms"
+                }
+            }
+        },
+        "main.ComponentHealth": {
+            "type": "object",
+            "properties": {
+                "details": {
+                    "description": "Details are the findings of the check, kafka.Health for Kafka",
+                    "type": "object"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "healthy"
+                }
+            }
+        },
+        "main.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "unexpected end of JSON input"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Invalid request body"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "timestamp": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string",
+                    "example": "1.0.0"
+                }
+            }
+        },
+        "main.HealthStatus": {
+            "type": "object",
+            "properties": {
+                "components": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/main.ComponentHealth"
+                    }
+                },
+                "status": {
+                    "type": "string",
+                    "example": "healthy"
+                },
+                "timestamp": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string",
+                    "example": "1.0.0"
+                }
+            }
+        },
+        "main.IncompatibleEvent": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/schemas.Change"
+                    }
+                },
+                "event_type": {
+                    "type": "string",
+                    "example": "form.submitted"
+                },
+                "version": {
+                    "description": "Version is the registered version the event was checked against",
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "main.PublishedEvent": {
+            "type": "object",
+            "properties": {
+                "deliver_at": {
+                    "description": "DeliverAt is the delivery time of scheduled events",
+                    "type": "string"
+                },
+                "event_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "published"
+                },
+                "topic": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.SchemaCheckRequest": {
+            "type": "object",
+            "properties": {
+                "sample": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "schema": {
+                    "$ref": "#/definitions/schemas.Schema"
+                }
+            }
+        },
+        "main.SwitchConsumersRequest": {
+            "type": "object",
+            "properties": {
+                "cluster": {
+                    "type": "string",
+                    "enum": [
+                        "primary",
+                        "secondary"
+                    ],
+                    "example": "secondary"
+                }
+            }
+        },
+        "main.VersionInfo": {
+            "type": "object",
+            "properties": {
+                "build_time": {
+                    "type": "string"
+                },
+                "git_commit": {
+                    "type": "string"
+                },
+                "go_version": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string",
+                    "example": "1.0.0"
+                }
+            }
+        },
+        "processors.ProcessorAssignment": {
+            "type": "object",
+            "properties": {
+                "group_id": {
+                    "description": "GroupID is the consumer group of the processors, kafka.consumer.group_id",
+                    "type": "string",
+                    "example": "event-bus-service-group"
+                },
+                "instance_id": {
+                    "description": "InstanceID is the static member ID of the replica, if any",
+                    "type": "string",
+                    "example": "event-bus-0"
+                },
+                "partitions": {
+                    "description": "Partitions are the partitions assigned, by topic. They are paused\nwhile no running processor handles their topic.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "type": "integer",
+                            "format": "int32"
+                        }
+                    }
+                },
+                "processor": {
+                    "type": "string",
+                    "example": "cdc-processor"
+                },
+                "state": {
+                    "type": "string",
+                    "example": "running"
+                },
+                "updated_at": {
+                    "description": "UpdatedAt is when partitions were last assigned or revoked",
+                    "type": "string"
+                }
+            }
+        },
+        "processors.ProcessorStatus": {
+            "type": "object",
+            "properties": {
+                "events_failed": {
+                    "type": "integer"
+                },
+                "events_processed": {
+                    "type": "integer"
+                },
+                "health_error": {
+                    "type": "string"
+                },
+                "healthy": {
+                    "description": "Healthy is false while the health check of a running processor fails",
+                    "type": "boolean"
+                },
+                "last_activity": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "description": "LastError is the last error or panic of the processor",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "cdc-processor"
+                },
+                "panics": {
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string",
+                    "example": "running"
+                },
+                "topics": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "type": "string",
+                    "example": "cdc"
+                }
+            }
+        },
+        "publishing.EventRequest": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "deliver_at": {
+                    "description": "DeliverAt schedules the event for delivery at a later time. Only POST\n/events accepts it.",
+                    "type": "string"
+                },
+                "event_type": {
+                    "type": "string"
+                },
+                "headers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "description": "ID is generated when empty",
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "subject": {
+                    "type": "string"
+                },
+                "topic": {
+                    "type": "string"
+                }
+            }
+        },
+        "scheduler.Event": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "cancelled_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "data": {
+                    "type": "object"
+                },
+                "deliver_at": {
+                    "type": "string"
+                },
+                "delivered_at": {
+                    "description": "DeliveredAt, Partition and Offset record the delivery",
+                    "type": "string"
+                },
+                "event_type": {
+                    "type": "string"
+                },
+                "headers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "description": "NextAttemptAt is when the event is next published: DeliverAt, or\nlater after failed attempts",
+                    "type": "string"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "partition": {
+                    "type": "integer"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "pending"
+                },
+                "subject": {
+                    "type": "string"
+                },
+                "topic": {
+                    "type": "string"
+                }
+            }
+        },
+        "scheduler.Page": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/scheduler.Event"
+                    }
+                },
+                "next_cursor": {
+                    "description": "NextCursor is passed as after for the following page, empty on the last",
+                    "type": "string"
+                }
+            }
+        },
+        "schemas.Change": {
+            "type": "object",
+            "properties": {
+                "compatible": {
+                    "description": "Compatible is true when the consumers of the registered schema can\nread data of the new one",
+                    "type": "boolean"
+                },
+                "kind": {
+                    "type": "string",
+                    "example": "removed_required_field"
+                },
+                "new": {
+                    "type": "string"
+                },
+                "old": {
+                    "type": "string",
+                    "example": "string"
+                },
+                "path": {
+                    "description": "Path of the field, dotted, with [] for the items of an array",
+                    "type": "string",
+                    "example": "respondent.email"
+                }
+            }
+        },
+        "schemas.CheckResult": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "description": "Changes from the latest version, compatible or not",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/schemas.Change"
+                    }
+                },
+                "compatible": {
+                    "type": "boolean"
+                },
+                "event_type": {
+                    "type": "string",
+                    "example": "form.submitted"
+                },
+                "latest_version": {
+                    "description": "LatestVersion is 0 when no schema is registered for the event type",
+                    "type": "integer",
+                    "example": 3
+                },
+                "schema": {
+                    "description": "Schema is the schema checked, inferred when a sample was given",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/schemas.Schema"
+                        }
+                    ]
+                }
+            }
+        },
+        "schemas.Schema": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "$ref": "#/definitions/schemas.Schema"
+                },
+                "properties": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/schemas.Schema"
+                    }
+                },
+                "required": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "description": "Type is empty when any value is accepted, as for a field seen null",
+                    "type": "string"
+                }
+            }
+        },
+        "schemas.Version": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "schema": {
+                    "$ref": "#/definitions/schemas.Schema"
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "description": "One of security.api_keys.keys. It may also be sent as \"Authorization: Bearer \u003ckey\u003e\".",
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        }
+    }
+}`
+
+// SwaggerInfo holds exported Swagger Info so clients can modify it
+var SwaggerInfo = &swag.Spec{
+	Version:          "1.0.0",
+	Host:             "",
+	BasePath:         "/",
+	Schemes:          []string{},
+	Title:            "Event Bus Service API",
+	Description:      "Event publishing, filtering and streaming over Kafka. Every JSON response is an APIResponse envelope; failures are documented as ErrorResponse.",
+	InfoInstanceName: "swagger",
+	SwaggerTemplate:  docTemplate,
+	LeftDelim:        "{{",
+	RightDelim:       "}}",
+}
+
+func init() {
+	swag.Register(SwaggerInfo.InstanceName(), SwaggerInfo)
+}
@@ -0,0 +1,245 @@
+// Package acl authorizes the topics the callers of the publish APIs publish
+// to. Callers are identified by principals, such as the SANs of their client
+// certificate, the name of their API key or the service claim of their JWT,
+// and security.publish_acl maps principals to the topic patterns they may
+// and may not publish to.
+package acl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+)
+
+// Kinds of principals
+const (
+	KindSAN     = "san"
+	KindAPIKey  = "api_key"
+	KindService = "svc"
+)
+
+// Reasons of decisions
+const (
+	// ReasonDeny is an explicit deny of a rule of the caller
+	ReasonDeny = "deny"
+	// ReasonAllow is an allow of a rule of the caller
+	ReasonAllow = "allow"
+	// ReasonProtected is a protected topic no rule of the caller allows
+	ReasonProtected = "protected"
+	// ReasonDefault is the default action, for topics no rule covers
+	ReasonDefault = "default"
+	// ReasonDisabled is the ACL being disabled
+	ReasonDisabled = "disabled"
+)
+
+// ErrForbidden is returned when the caller may not publish to the topic
+var ErrForbidden = errors.New("publishing to the topic is forbidden")
+
+// Principal formats a principal of kind
+func Principal(kind, name string) string {
+	return kind + ":" + name
+}
+
+// Decision is the outcome of authorizing a publish
+type Decision struct {
+	Allowed bool   `json:"allowed"`
+	Topic   string `json:"topic"`
+	// Principal and Pattern are the principal of the rule and the topic
+	// pattern that decided, if any
+	Principal string `json:"principal,omitempty"`
+	Pattern   string `json:"pattern,omitempty"`
+	Reason    string `json:"reason"`
+}
+
+// Auditor records the publishes denied to callers
+type Auditor func(ctx context.Context, caller *Caller, decision Decision)
+
+// ACL authorizes publishes against security.publish_acl. It implements
+// publishing.Authorizer and config.ConfigReloader.
+type ACL struct {
+	metrics *Metrics
+	logger  *zap.Logger
+
+	mutex   sync.RWMutex
+	cfg     config.PublishACLConfig
+	auditor Auditor
+}
+
+// New creates an ACL enforcing cfg
+func New(cfg config.PublishACLConfig, metrics *Metrics, logger *zap.Logger) *ACL {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &ACL{cfg: cfg, metrics: metrics, logger: logger}
+}
+
+// SetAuditor records the denied publishes with auditor
+func (a *ACL) SetAuditor(auditor Auditor) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.auditor = auditor
+}
+
+// ReloadConfig switches to the reloaded security.publish_acl
+func (a *ACL) ReloadConfig(cfg *config.Config) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.cfg = cfg.Security.PublishACL
+	a.logger.Info("Publish ACL reloaded",
+		zap.Bool("enabled", a.cfg.Enabled),
+		zap.Int("rules", len(a.cfg.Rules)))
+	return nil
+}
+
+// Effective returns the ACL being enforced
+func (a *ACL) Effective() config.PublishACLConfig {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return a.cfg
+}
+
+// Decide decides whether principals may publish to topic. An explicit deny
+// of any rule matching one of the principals wins; then an allow; then
+// protected topics are denied; the default action decides the rest.
+func (a *ACL) Decide(principals []string, topic string) Decision {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return decide(a.cfg, principals, topic)
+}
+
+func decide(cfg config.PublishACLConfig, principals []string, topic string) Decision {
+	if !cfg.Enabled {
+		return Decision{Allowed: true, Topic: topic, Reason: ReasonDisabled}
+	}
+
+	var allow *Decision
+	for _, rule := range cfg.Rules {
+		if !matchPrincipal(rule.Principal, principals) {
+			continue
+		}
+		for _, pattern := range rule.Deny {
+			if Match(pattern, topic) {
+				return Decision{Topic: topic, Principal: rule.Principal, Pattern: pattern, Reason: ReasonDeny}
+			}
+		}
+		if allow != nil {
+			continue
+		}
+		for _, pattern := range rule.Allow {
+			if Match(pattern, topic) {
+				allow = &Decision{Allowed: true, Topic: topic, Principal: rule.Principal, Pattern: pattern, Reason: ReasonAllow}
+				break
+			}
+		}
+	}
+	if allow != nil {
+		return *allow
+	}
+
+	for _, pattern := range cfg.ProtectedTopics {
+		if Match(pattern, topic) {
+			return Decision{Topic: topic, Pattern: pattern, Reason: ReasonProtected}
+		}
+	}
+	return Decision{Allowed: cfg.DefaultAction != "deny", Topic: topic, Reason: ReasonDefault}
+}
+
+// Authorize checks that the caller of ctx may publish to topic. Publishes
+// without a caller are made by the service itself and always allowed.
+// Denials wrap ErrForbidden, are counted and audited.
+func (a *ACL) Authorize(ctx context.Context, topic string) error {
+	caller, ok := FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	decision := a.Decide(caller.Principals, topic)
+	if decision.Allowed {
+		return nil
+	}
+
+	principal := "anonymous"
+	if len(caller.Principals) > 0 {
+		principal = caller.Principals[0]
+	}
+	a.metrics.Denied.WithLabelValues(principal, decision.Reason).Inc()
+	a.logger.Warn("Publish denied by the ACL",
+		zap.Strings("principals", caller.Principals),
+		zap.String("topic", topic),
+		zap.String("reason", decision.Reason),
+		zap.String("pattern", decision.Pattern))
+
+	a.mutex.RLock()
+	auditor := a.auditor
+	a.mutex.RUnlock()
+	if auditor != nil {
+		auditor(ctx, caller, decision)
+	}
+	return fmt.Errorf("%w: %s (%s)", ErrForbidden, topic, decision.Reason)
+}
+
+// matchPrincipal reports whether the principal of a rule matches one of
+// principals. A rule principal named * matches every principal of its kind.
+func matchPrincipal(rule string, principals []string) bool {
+	kind, name, _ := strings.Cut(rule, ":")
+	for _, principal := range principals {
+		if principal == rule {
+			return true
+		}
+		if name == "*" && strings.HasPrefix(principal, kind+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// Match reports whether topic matches pattern, where * matches any run of
+// characters, dots included
+func Match(pattern, topic string) bool {
+	// Greedy matching with backtracking to the last star
+	p, t := 0, 0
+	star, resume := -1, 0
+	for t < len(topic) {
+		switch {
+		case p < len(pattern) && pattern[p] == '*':
+			star, resume = p, t
+			p++
+		case p < len(pattern) && pattern[p] == topic[t]:
+			p++
+			t++
+		case star >= 0:
+			resume++
+			p, t = star+1, resume
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// Metrics contains the Prometheus metrics of the ACL
+type Metrics struct {
+	Denied *prometheus.CounterVec
+}
+
+// NewMetrics creates the ACL metrics and registers them with reg
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		Denied: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "eventbus_publish_denied_total",
+			Help: "Total number of publishes denied by the publish ACL, by principal and reason",
+		}, []string{"principal", "reason"}),
+	}
+
+	reg.MustRegister(m.Denied)
+	return m
+}
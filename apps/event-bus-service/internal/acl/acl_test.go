@@ -0,0 +1,177 @@
+package acl
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, topic string
+		want           bool
+	}{
+		{"form.response.created", "form.response.created", true},
+		{"form.response.created", "form.response.updated", false},
+		{"form.*", "form.response.created", true},
+		{"form.*", "form.", true},
+		{"form.*", "forms.created", false},
+		{"cdc.*", "cdc.public.forms", true},
+		{"*.dlq", "form.response.dlq", true},
+		{"*.dlq", "form.dlq.replay", false},
+		{"*.response.*", "form.response.created", true},
+		{"*.response.*", "form.responses", false},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "axxbyyb", false},
+		{"*", "anything.at.all", true},
+		{"**", "", true},
+		{"", "", true},
+		{"", "form", false},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.topic); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.topic, got, tt.want)
+		}
+	}
+}
+
+func testConfig() config.PublishACLConfig {
+	return config.PublishACLConfig{
+		Enabled:         true,
+		DefaultAction:   "allow",
+		ProtectedTopics: []string{"cdc.*", "*.dlq"},
+		Rules: []config.PublishACLRule{
+			{Principal: "svc:response-service", Allow: []string{"form.response.*", "*.dlq"}, Deny: []string{"form.response.deleted"}},
+			{Principal: "svc:*", Deny: []string{"audit.*"}},
+			{Principal: "san:debezium.internal", Allow: []string{"cdc.*"}},
+			{Principal: "api_key:analytics", Deny: []string{"*"}},
+		},
+	}
+}
+
+func TestDecide(t *testing.T) {
+	a := New(testConfig(), NewMetrics(prometheus.NewRegistry()), nil)
+
+	tests := []struct {
+		name       string
+		principals []string
+		topic      string
+		want       bool
+		reason     string
+	}{
+		{"allowed", []string{"svc:response-service"}, "form.response.created", true, ReasonAllow},
+		{"deny wins over allow", []string{"svc:response-service"}, "form.response.deleted", false, ReasonDeny},
+		{"deny of a wildcard principal wins", []string{"svc:response-service"}, "audit.events", false, ReasonDeny},
+		{"deny of another principal wins", []string{"san:debezium.internal", "api_key:analytics"}, "cdc.public.forms", false, ReasonDeny},
+		{"protected topic allowed", []string{"san:debezium.internal"}, "cdc.public.forms", true, ReasonAllow},
+		{"protected dlq allowed", []string{"svc:response-service"}, "form.response.dlq", true, ReasonAllow},
+		{"protected topic", []string{"svc:form-service"}, "cdc.public.forms", false, ReasonProtected},
+		{"protected without principals", nil, "form.dlq", false, ReasonProtected},
+		{"default", []string{"svc:form-service"}, "form.created", true, ReasonDefault},
+		{"default without principals", nil, "form.created", true, ReasonDefault},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := a.Decide(tt.principals, tt.topic)
+			if got.Allowed != tt.want || got.Reason != tt.reason {
+				t.Errorf("Decide(%v, %q) = %+v, want allowed %v for %s", tt.principals, tt.topic, got, tt.want, tt.reason)
+			}
+		})
+	}
+
+	cfg := testConfig()
+	cfg.DefaultAction = "deny"
+	if got := decide(cfg, []string{"svc:form-service"}, "form.created"); got.Allowed {
+		t.Errorf("default deny: Decide = %+v, want denied", got)
+	}
+	cfg.Enabled = false
+	if got := decide(cfg, nil, "cdc.public.forms"); !got.Allowed {
+		t.Errorf("disabled: Decide = %+v, want allowed", got)
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	metrics := NewMetrics(prometheus.NewRegistry())
+	a := New(testConfig(), metrics, nil)
+	var audited []Decision
+	a.SetAuditor(func(_ context.Context, caller *Caller, decision Decision) {
+		audited = append(audited, decision)
+	})
+
+	// The service's own publishes carry no caller
+	if err := a.Authorize(context.Background(), "cdc.public.forms"); err != nil {
+		t.Errorf("Authorize() without a caller = %v, want nil", err)
+	}
+
+	ctx := NewContext(context.Background(), &Caller{Principals: []string{"svc:form-service"}})
+	if err := a.Authorize(ctx, "form.created"); err != nil {
+		t.Errorf("Authorize() = %v, want nil", err)
+	}
+	if err := a.Authorize(ctx, "cdc.public.forms"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Authorize() = %v, want ErrForbidden", err)
+	}
+	if got := testutil.ToFloat64(metrics.Denied.WithLabelValues("svc:form-service", ReasonProtected)); got != 1 {
+		t.Errorf("denied = %v, want 1", got)
+	}
+	if len(audited) != 1 || audited[0].Topic != "cdc.public.forms" || audited[0].Pattern != "cdc.*" {
+		t.Errorf("audited %+v, want the denied publish", audited)
+	}
+
+	// Reloading switches the rules
+	cfg := &config.Config{}
+	cfg.Security.PublishACL = testConfig()
+	cfg.Security.PublishACL.Enabled = false
+	if err := a.ReloadConfig(cfg); err != nil {
+		t.Fatalf("ReloadConfig: %v", err)
+	}
+	if err := a.Authorize(ctx, "cdc.public.forms"); err != nil {
+		t.Errorf("Authorize() once disabled = %v, want nil", err)
+	}
+}
+
+func TestPrincipals(t *testing.T) {
+	auth := NewAuthenticator(config.SecurityConfig{
+		JWT:     config.JWTConfig{Secret: "jwt-secret", Issuer: "x-form"},
+		APIKeys: config.APIKeysConfig{Enabled: true, Keys: map[string]string{"analytics": "a-key"}},
+	})
+	sign := func(secret, issuer string) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"svc": "response-service",
+			"iss": issuer,
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}).SignedString([]byte(secret))
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name           string
+		apiKey, bearer string
+		want           string
+	}{
+		{"api key header", "a-key", "", "api_key:analytics"},
+		{"api key as bearer", "", "a-key", "api_key:analytics"},
+		{"service token", "", sign("jwt-secret", "x-form"), "svc:response-service"},
+		{"token of another secret", "", sign("other", "x-form"), ""},
+		{"token of another issuer", "", sign("jwt-secret", "elsewhere"), ""},
+		{"unknown key", "guess", "", ""},
+		{"nothing", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := auth.Principals(nil, tt.apiKey, tt.bearer)
+			if (tt.want == "" && len(got) != 0) || (tt.want != "" && (len(got) != 1 || got[0] != tt.want)) {
+				t.Errorf("Principals() = %v, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package acl
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+)
+
+// Caller is the client of a publish API
+type Caller struct {
+	Principals []string
+	// IP and RequestID identify the request in audit events
+	IP        string
+	RequestID string
+}
+
+type callerKey struct{}
+
+// NewContext returns a copy of ctx carrying caller
+func NewContext(ctx context.Context, caller *Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// FromContext returns the caller carried by ctx, if any
+func FromContext(ctx context.Context) (*Caller, bool) {
+	caller, ok := ctx.Value(callerKey{}).(*Caller)
+	return caller, ok
+}
+
+// Authenticator finds the principals of callers from their credentials
+type Authenticator struct {
+	apiKeys   config.APIKeysConfig
+	jwtSecret []byte
+	jwtIssuer string
+}
+
+// NewAuthenticator creates an authenticator checking credentials against
+// security.api_keys and security.jwt
+func NewAuthenticator(cfg config.SecurityConfig) *Authenticator {
+	return &Authenticator{
+		apiKeys:   cfg.APIKeys,
+		jwtSecret: []byte(cfg.JWT.Secret),
+		jwtIssuer: cfg.JWT.Issuer,
+	}
+}
+
+// Principals returns the principals proven by the verified client
+// certificate of state, an API key and a bearer token, any of which may be
+// missing. The bearer token is either an API key or a JWT with a svc claim.
+// Credentials that don't check out give no principal.
+func (a *Authenticator) Principals(state *tls.ConnectionState, apiKey, bearer string) []string {
+	var principals []string
+	if state != nil && len(state.VerifiedChains) > 0 && len(state.PeerCertificates) > 0 {
+		cert := state.PeerCertificates[0]
+		for _, name := range cert.DNSNames {
+			principals = append(principals, Principal(KindSAN, name))
+		}
+		for _, uri := range cert.URIs {
+			principals = append(principals, Principal(KindSAN, uri.String()))
+		}
+	}
+
+	if apiKey == "" {
+		apiKey = bearer
+	}
+	if name, ok := a.apiKeyName(apiKey); ok {
+		principals = append(principals, Principal(KindAPIKey, name))
+	} else if service, ok := a.service(bearer); ok {
+		principals = append(principals, Principal(KindService, service))
+	}
+	return principals
+}
+
+// apiKeyName returns the name of the API key key
+func (a *Authenticator) apiKeyName(key string) (string, bool) {
+	if !a.apiKeys.Enabled || key == "" {
+		return "", false
+	}
+	found := ""
+	for name, candidate := range a.apiKeys.Keys {
+		if candidate != "" && subtle.ConstantTimeCompare([]byte(key), []byte(candidate)) == 1 {
+			found = name
+		}
+	}
+	return found, found != ""
+}
+
+// service returns the svc claim of a JWT signed with the JWT secret
+func (a *Authenticator) service(token string) (string, bool) {
+	if len(a.jwtSecret) == 0 || token == "" {
+		return "", false
+	}
+	options := []jwt.ParserOption{jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()})}
+	if a.jwtIssuer != "" {
+		options = append(options, jwt.WithIssuer(a.jwtIssuer))
+	}
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return a.jwtSecret, nil
+	}, options...)
+	if err != nil {
+		return "", false
+	}
+	service, _ := claims["svc"].(string)
+	return service, service != ""
+}
@@ -0,0 +1,308 @@
+// Package anomaly throttles forms whose submissions surge. The detector
+// counts the form.response.created events of each form per minute, test
+// submissions aside, and compares the rate over a rolling window against a
+// baseline: the exponentially weighted moving average of the submissions per
+// minute over the baseline period, 24 hours by default. A form is throttled
+// once its rate reaches the multiplier of its thresholds times the baseline,
+// and at least their minimum rate. Throttled forms require CAPTCHA and a
+// stricter per-IP limit from respondents; the form service records the
+// throttling and publishes the events owners are notified of. The baseline is
+// frozen while a form is throttled, so that the surge is not learned, and the
+// form is released once its rate falls under half the threshold.
+package anomaly
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/projections"
+)
+
+// lookbackWindows is the number of windows of counts kept, and folded into
+// the baseline one minute at a time; older minutes are folded as empty
+const lookbackWindows = 2
+
+// Detector counts submissions and throttles the forms whose rate surges. It
+// implements kafka.BatchConsumerHandler.
+type Detector struct {
+	store   Store
+	forms   Forms
+	metrics *Metrics
+	logger  *zap.Logger
+
+	topics   []string
+	groupID  string
+	window   int64
+	lookback int64
+	alpha    float64
+	interval time.Duration
+	now      func() time.Time
+}
+
+// New creates a detector counting in store and throttling through forms
+func New(cfg config.AnomalyConfig, store Store, forms Forms, metrics *Metrics, logger *zap.Logger) *Detector {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	window := int64(cfg.Window / time.Minute)
+	if window < 1 {
+		window = 1
+	}
+	return &Detector{
+		store:    store,
+		forms:    forms,
+		metrics:  metrics,
+		logger:   logger,
+		topics:   cfg.Topics,
+		groupID:  cfg.GroupID,
+		window:   window,
+		lookback: lookbackWindows * window,
+		alpha:    2 / (cfg.BaselinePeriod.Minutes() + 1),
+		interval: cfg.EvaluationInterval,
+		now:      time.Now,
+	}
+}
+
+// CountTTL is how long the counts of a minute must be kept for cfg
+func CountTTL(cfg config.AnomalyConfig) time.Duration {
+	return time.Duration(lookbackWindows+1) * cfg.Window
+}
+
+// GetTopics returns the topics the detector consumes
+func (d *Detector) GetTopics() []string {
+	return d.topics
+}
+
+// GetGroupID returns the consumer group the detector commits offsets in
+func (d *Detector) GetGroupID() string {
+	return d.groupID
+}
+
+// HandleBatch counts the submissions of a batch, then evaluates each form
+// submitted to. Only failures to count fail the batch; a form that can't be
+// evaluated is evaluated again on its next submission.
+func (d *Detector) HandleBatch(ctx context.Context, messages []*kafka.Message) error {
+	counts := make(map[string]map[int64]int64)
+	for _, message := range messages {
+		if message.EventType != projections.ResponseCreatedEventType {
+			d.metrics.Events.WithLabelValues("skipped").Inc()
+			continue
+		}
+		event, err := projections.ParseResponseEvent(message)
+		if err != nil {
+			d.logger.Warn("Dropping invalid response event", zap.String("event_id", message.ID), zap.Error(err))
+			d.metrics.Events.WithLabelValues("invalid").Inc()
+			continue
+		}
+		// Test submissions are not traffic
+		if event.Test {
+			d.metrics.Events.WithLabelValues("test").Inc()
+			continue
+		}
+
+		submitted := event.SubmittedAt
+		if submitted.IsZero() || submitted.After(d.now()) {
+			submitted = d.now()
+		}
+		if counts[event.FormID] == nil {
+			counts[event.FormID] = make(map[int64]int64)
+		}
+		counts[event.FormID][minuteOf(submitted)]++
+	}
+
+	formIDs := make([]string, 0, len(counts))
+	for formID, minutes := range counts {
+		for minute, n := range minutes {
+			if err := d.store.Add(ctx, formID, minute, n); err != nil {
+				return err
+			}
+			d.metrics.Events.WithLabelValues("counted").Add(float64(n))
+		}
+		formIDs = append(formIDs, formID)
+	}
+	sort.Strings(formIDs)
+
+	for _, formID := range formIDs {
+		if _, err := d.evaluate(ctx, formID); err != nil {
+			d.evaluationFailed(formID, err)
+		}
+	}
+	return nil
+}
+
+// Run re-evaluates the throttled forms every evaluation interval until ctx is
+// done, releasing those whose submissions normalized or stopped
+func (d *Detector) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.evaluateThrottled(ctx)
+		}
+	}
+}
+
+// evaluateThrottled re-evaluates the throttled forms
+func (d *Detector) evaluateThrottled(ctx context.Context) {
+	formIDs, err := d.store.Throttled(ctx)
+	if err != nil {
+		d.logger.Warn("Failed to list throttled forms", zap.Error(err))
+		return
+	}
+
+	throttled := 0
+	for _, formID := range formIDs {
+		stillThrottled, err := d.evaluate(ctx, formID)
+		if err != nil {
+			d.evaluationFailed(formID, err)
+			throttled++
+			continue
+		}
+		if stillThrottled {
+			throttled++
+		}
+	}
+	d.metrics.Throttled.Set(float64(throttled))
+}
+
+// evaluate updates the baseline of a form and throttles or releases it as its
+// rate asks, returning whether it is throttled
+func (d *Detector) evaluate(ctx context.Context, formID string) (bool, error) {
+	state, err := d.forms.State(ctx, formID)
+	if errors.Is(err, ErrFormNotFound) {
+		return false, d.store.SetThrottled(ctx, formID, false)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	now := minuteOf(d.now())
+	first := now - d.lookback + 1
+	counts, err := d.store.Counts(ctx, formID, first, now)
+	if err != nil {
+		return false, err
+	}
+	var recent int64
+	for _, count := range counts[len(counts)-int(d.window):] {
+		recent += count
+	}
+	rate := float64(recent) / float64(d.window)
+
+	baseline, found, err := d.store.Baseline(ctx, formID)
+	if err != nil {
+		return false, err
+	}
+	switch {
+	case !found:
+		baseline = Baseline{Minute: now - 1}
+	case state.Throttled:
+		baseline.Minute = now - 1
+	default:
+		baseline = d.fold(baseline, counts, first, now-1)
+	}
+	if err := d.store.SetBaseline(ctx, formID, baseline); err != nil {
+		return false, err
+	}
+
+	thresholds := state.Throttling
+	threshold := math.Max(thresholds.MinRatePerMinute, thresholds.Multiplier*baseline.Value)
+	throttle := state.Throttled
+	switch {
+	case !state.Throttled && !thresholds.Disabled && rate >= threshold:
+		throttle = true
+	case state.Throttled && (thresholds.Disabled || rate < threshold/2):
+		throttle = false
+	}
+	if throttle == state.Throttled {
+		if state.Throttled {
+			return true, d.store.SetThrottled(ctx, formID, true)
+		}
+		return false, nil
+	}
+
+	change := Change{Throttled: throttle, RatePerMinute: rate, BaselinePerMinute: baseline.Value}
+	updated, err := d.forms.SetThrottled(ctx, formID, change)
+	if err != nil {
+		return state.Throttled, fmt.Errorf("failed to change the throttling: %w", err)
+	}
+	if err := d.store.SetThrottled(ctx, formID, updated.Throttled); err != nil {
+		return updated.Throttled, err
+	}
+
+	action := "released"
+	if updated.Throttled {
+		action = "engaged"
+	}
+	d.metrics.Changes.WithLabelValues(action).Inc()
+	d.logger.Info("Form throttling "+action,
+		zap.String("form_id", formID),
+		zap.Float64("rate_per_minute", rate),
+		zap.Float64("baseline_per_minute", baseline.Value),
+		zap.Float64("threshold_per_minute", threshold))
+	return updated.Throttled, nil
+}
+
+// fold folds the minutes after the baseline up to last into it. counts are
+// the counts of the minutes from first; minutes before first have expired
+// and are folded as empty.
+func (d *Detector) fold(baseline Baseline, counts []int64, first, last int64) Baseline {
+	if skipped := first - 1 - baseline.Minute; skipped > 0 {
+		baseline.Value *= math.Pow(1-d.alpha, float64(skipped))
+		baseline.Minute = first - 1
+	}
+	for minute := baseline.Minute + 1; minute <= last; minute++ {
+		baseline.Value += d.alpha * (float64(counts[minute-first]) - baseline.Value)
+		baseline.Minute = minute
+	}
+	return baseline
+}
+
+func (d *Detector) evaluationFailed(formID string, err error) {
+	d.logger.Warn("Failed to evaluate the submission rate of a form", zap.String("form_id", formID), zap.Error(err))
+	d.metrics.Events.WithLabelValues("evaluation_failed").Inc()
+}
+
+// minuteOf returns the minutes since the Unix epoch of t
+func minuteOf(t time.Time) int64 {
+	return t.Unix() / 60
+}
+
+// Metrics contains the Prometheus metrics of the detector
+type Metrics struct {
+	Events    *prometheus.CounterVec
+	Changes   *prometheus.CounterVec
+	Throttled prometheus.Gauge
+}
+
+// NewMetrics creates the detector metrics and registers them with reg
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		Events: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "eventbus_anomaly_events_total",
+			Help: "Total number of events consumed by the anomaly detector, by outcome",
+		}, []string{"status"}),
+		Changes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "eventbus_anomaly_throttle_changes_total",
+			Help: "Total number of forms throttled and released, by action",
+		}, []string{"action"}),
+		Throttled: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "eventbus_anomaly_throttled_forms",
+			Help: "Number of forms throttled at the last evaluation",
+		}),
+	}
+	reg.MustRegister(m.Events, m.Changes, m.Throttled)
+	return m
+}
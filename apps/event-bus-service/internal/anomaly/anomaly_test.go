@@ -0,0 +1,218 @@
+package anomaly
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/projections"
+)
+
+// memoryStore is a Store in memory
+type memoryStore struct {
+	counts    map[string]int64
+	baselines map[string]Baseline
+	throttled map[string]bool
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{counts: map[string]int64{}, baselines: map[string]Baseline{}, throttled: map[string]bool{}}
+}
+
+func (s *memoryStore) Add(_ context.Context, formID string, minute, n int64) error {
+	s.counts[fmt.Sprint(formID, minute)] += n
+	return nil
+}
+
+func (s *memoryStore) Counts(_ context.Context, formID string, first, last int64) ([]int64, error) {
+	var counts []int64
+	for minute := first; minute <= last; minute++ {
+		counts = append(counts, s.counts[fmt.Sprint(formID, minute)])
+	}
+	return counts, nil
+}
+
+func (s *memoryStore) Baseline(_ context.Context, formID string) (Baseline, bool, error) {
+	baseline, ok := s.baselines[formID]
+	return baseline, ok, nil
+}
+
+func (s *memoryStore) SetBaseline(_ context.Context, formID string, baseline Baseline) error {
+	s.baselines[formID] = baseline
+	return nil
+}
+
+func (s *memoryStore) Throttled(context.Context) ([]string, error) {
+	var forms []string
+	for formID := range s.throttled {
+		forms = append(forms, formID)
+	}
+	return forms, nil
+}
+
+func (s *memoryStore) SetThrottled(_ context.Context, formID string, throttled bool) error {
+	if throttled {
+		s.throttled[formID] = true
+	} else {
+		delete(s.throttled, formID)
+	}
+	return nil
+}
+
+// memoryForms records the changes of the throttling of forms
+type memoryForms struct {
+	states  map[string]*State
+	changes []Change
+}
+
+func (f *memoryForms) State(_ context.Context, formID string) (*State, error) {
+	state, ok := f.states[formID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrFormNotFound, formID)
+	}
+	copied := *state
+	return &copied, nil
+}
+
+func (f *memoryForms) SetThrottled(ctx context.Context, formID string, change Change) (*State, error) {
+	f.changes = append(f.changes, change)
+	f.states[formID].Throttled = change.Throttled
+	return f.State(ctx, formID)
+}
+
+func responses(formID string, n int, at time.Time) []*kafka.Message {
+	messages := make([]*kafka.Message, n)
+	for i := range messages {
+		data, _ := json.Marshal(map[string]interface{}{
+			"response_id":  fmt.Sprintf("r-%d-%d", at.Unix(), i),
+			"form_id":      formID,
+			"answers_hash": "sha256:0",
+			"submitted_at": at,
+		})
+		messages[i] = &kafka.Message{ID: fmt.Sprintf("e-%d-%d", at.Unix(), i), EventType: projections.ResponseCreatedEventType, Data: json.RawMessage(data)}
+	}
+	return messages
+}
+
+func TestDetectorThrottlesSurges(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	forms := &memoryForms{states: map[string]*State{
+		"form-1": {FormID: "form-1", Throttling: Thresholds{Multiplier: 10, MinRatePerMinute: 5}},
+	}}
+	cfg := config.AnomalyConfig{Window: 5 * time.Minute, BaselinePeriod: 24 * time.Hour, EvaluationInterval: time.Minute}
+	d := New(cfg, store, forms, NewMetrics(prometheus.NewRegistry()), nil)
+	clock := time.Date(2024, 3, 1, 12, 0, 30, 0, time.UTC)
+	d.now = func() time.Time { return clock }
+
+	// Two submissions a minute for a day set the baseline, the average
+	// weighing the last day 86%
+	for i := 0; i < 24*60; i++ {
+		if err := d.HandleBatch(ctx, responses("form-1", 2, clock)); err != nil {
+			t.Fatal(err)
+		}
+		clock = clock.Add(time.Minute)
+	}
+	baseline := store.baselines["form-1"].Value
+	if baseline < 1.7 || baseline > 1.8 || len(forms.changes) != 0 {
+		t.Fatalf("baseline = %.2f with changes %v, want about 1.73 and none", baseline, forms.changes)
+	}
+
+	// A surge of 100 a minute passes 10 times the baseline within the window
+	for i := 0; i < 3 && len(forms.changes) == 0; i++ {
+		if err := d.HandleBatch(ctx, responses("form-1", 100, clock)); err != nil {
+			t.Fatal(err)
+		}
+		clock = clock.Add(time.Minute)
+	}
+	if len(forms.changes) != 1 || !forms.changes[0].Throttled || !store.throttled["form-1"] {
+		t.Fatalf("changes = %+v, want the form throttled", forms.changes)
+	}
+	if forms.changes[0].RatePerMinute < 20 || math.Abs(forms.changes[0].BaselinePerMinute-baseline) > 0.5 {
+		t.Errorf("engaged with %+v, want the surge rate and the baseline before it", forms.changes[0])
+	}
+
+	// The baseline is frozen while throttled, and the form released once
+	// submissions stop
+	for i := 0; i < 10; i++ {
+		d.evaluateThrottled(ctx)
+		clock = clock.Add(time.Minute)
+	}
+	if got := store.baselines["form-1"].Value; math.Abs(got-baseline) > 0.5 {
+		t.Errorf("baseline after the surge = %.2f, want it frozen near %.2f", got, baseline)
+	}
+	if len(forms.changes) != 2 || forms.changes[1].Throttled || store.throttled["form-1"] {
+		t.Errorf("changes = %+v, want the form released", forms.changes)
+	}
+}
+
+func TestDetectorRespectsThresholds(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	forms := &memoryForms{states: map[string]*State{
+		"quiet":    {FormID: "quiet", Throttling: Thresholds{Multiplier: 100, MinRatePerMinute: 30}},
+		"disabled": {FormID: "disabled", Throttling: Thresholds{Disabled: true, Multiplier: 100, MinRatePerMinute: 30}},
+	}}
+	cfg := config.AnomalyConfig{Window: 5 * time.Minute, BaselinePeriod: 24 * time.Hour, EvaluationInterval: time.Minute}
+	d := New(cfg, store, forms, NewMetrics(prometheus.NewRegistry()), nil)
+	clock := time.Date(2024, 3, 1, 12, 0, 30, 0, time.UTC)
+	d.now = func() time.Time { return clock }
+
+	// A new form under the minimum rate, a disabled form and an unknown one
+	// are not throttled
+	batch := append(responses("quiet", 100, clock), responses("disabled", 1000, clock)...)
+	batch = append(batch, responses("deleted", 1000, clock)...)
+	if err := d.HandleBatch(ctx, batch); err != nil {
+		t.Fatal(err)
+	}
+	if len(forms.changes) != 0 || len(store.throttled) != 0 {
+		t.Errorf("changes = %+v, want none", forms.changes)
+	}
+
+	// The minimum rate applies to forms without a baseline
+	if err := d.HandleBatch(ctx, responses("quiet", 100, clock)); err != nil {
+		t.Fatal(err)
+	}
+	if len(forms.changes) != 1 || !forms.changes[0].Throttled {
+		t.Errorf("changes = %+v, want the form throttled at 40 a minute", forms.changes)
+	}
+}
+
+func TestDetectorIgnoresTestSubmissions(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	forms := &memoryForms{states: map[string]*State{
+		"form-1": {FormID: "form-1", Throttling: Thresholds{Multiplier: 10, MinRatePerMinute: 5}},
+	}}
+	cfg := config.AnomalyConfig{Window: 5 * time.Minute, BaselinePeriod: 24 * time.Hour, EvaluationInterval: time.Minute}
+	metrics := NewMetrics(prometheus.NewRegistry())
+	d := New(cfg, store, forms, metrics, nil)
+	clock := time.Date(2024, 3, 1, 12, 0, 30, 0, time.UTC)
+	d.now = func() time.Time { return clock }
+
+	// Owners testing a form hundreds of times don't make a surge
+	batch := responses("form-1", 500, clock)
+	for _, message := range batch {
+		var data map[string]interface{}
+		json.Unmarshal(message.Data.(json.RawMessage), &data)
+		data["is_test"] = true
+		message.Data, _ = json.Marshal(data)
+	}
+	if err := d.HandleBatch(ctx, batch); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.counts) != 0 || len(forms.changes) != 0 {
+		t.Errorf("counts = %v with changes %+v, want none", store.counts, forms.changes)
+	}
+	if got := testutil.ToFloat64(metrics.Events.WithLabelValues("test")); got != 500 {
+		t.Errorf("test events = %v, want 500", got)
+	}
+}
@@ -0,0 +1,135 @@
+package anomaly
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrFormNotFound is returned for forms the form service does not know
+var ErrFormNotFound = errors.New("form not found")
+
+// Thresholds tune when a form is throttled: once its rate reaches
+// Multiplier times its baseline, and at least MinRatePerMinute. The form
+// service fills in the defaults.
+type Thresholds struct {
+	Disabled         bool    `json:"disabled,omitempty"`
+	Multiplier       float64 `json:"multiplier"`
+	MinRatePerMinute float64 `json:"min_rate_per_minute"`
+}
+
+// State is whether a form is throttled, and its thresholds
+type State struct {
+	FormID     string     `json:"form_id"`
+	Throttled  bool       `json:"throttled"`
+	Throttling Thresholds `json:"throttling"`
+}
+
+// Change throttles or releases a form, with the rates it was decided on
+type Change struct {
+	Throttled         bool    `json:"throttled"`
+	RatePerMinute     float64 `json:"rate_per_minute"`
+	BaselinePerMinute float64 `json:"baseline_per_minute"`
+}
+
+// Forms reads and records the throttling of forms
+type Forms interface {
+	State(ctx context.Context, formID string) (*State, error)
+	SetThrottled(ctx context.Context, formID string, change Change) (*State, error)
+}
+
+// FormClient reads and records throttling through the internal API of the
+// form service, caching the states for a TTL so bursts of submissions to a
+// form cost a single lookup
+type FormClient struct {
+	baseURL string
+	client  *http.Client
+	ttl     time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedState
+}
+
+type cachedState struct {
+	state   *State
+	expires time.Time
+}
+
+// NewFormClient creates a client of the form service at baseURL
+func NewFormClient(baseURL string, ttl time.Duration) *FormClient {
+	return &FormClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: 5 * time.Second},
+		ttl:     ttl,
+		cache:   make(map[string]cachedState),
+	}
+}
+
+// State returns whether the form is throttled, and its thresholds
+func (c *FormClient) State(ctx context.Context, formID string) (*State, error) {
+	c.mu.Lock()
+	cached, ok := c.cache[formID]
+	c.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.state, nil
+	}
+	return c.do(ctx, http.MethodGet, formID, nil)
+}
+
+// SetThrottled throttles or releases the form
+func (c *FormClient) SetThrottled(ctx context.Context, formID string, change Change) (*State, error) {
+	body, err := json.Marshal(change)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(ctx, http.MethodPut, formID, body)
+}
+
+// do calls the throttling endpoint of the form, caching the state answered
+func (c *FormClient) do(ctx context.Context, method, formID string, body []byte) (*State, error) {
+	req, err := http.NewRequestWithContext(ctx, method,
+		c.baseURL+"/internal/forms/"+url.PathEscape(formID)+"/throttling", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call the throttling of form %s: %w", formID, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrFormNotFound, formID)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("form service answered %d for the throttling of %s", resp.StatusCode, formID)
+	}
+
+	var state State
+	if err := json.NewDecoder(resp.Body).Decode(&state); err != nil {
+		return nil, fmt.Errorf("invalid throttling: %w", err)
+	}
+
+	if c.ttl > 0 {
+		c.mu.Lock()
+		now := time.Now()
+		for id, entry := range c.cache {
+			if now.After(entry.expires) {
+				delete(c.cache, id)
+			}
+		}
+		c.cache[formID] = cachedState{state: &state, expires: now.Add(c.ttl)}
+		c.mu.Unlock()
+	}
+	return &state, nil
+}
@@ -0,0 +1,150 @@
+package anomaly
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Baseline is the moving average of the submissions per minute of a form,
+// folded up to Minute, in minutes since the Unix epoch
+type Baseline struct {
+	Value  float64
+	Minute int64
+}
+
+// Store keeps the submission counts per form and minute, the baselines and
+// the forms throttled. It is shared by the instances of the detector.
+type Store interface {
+	// Add counts n submissions to a form in a minute
+	Add(ctx context.Context, formID string, minute, n int64) error
+	// Counts returns the submissions of a form in each minute from first to
+	// last, zero for minutes not counted or expired
+	Counts(ctx context.Context, formID string, first, last int64) ([]int64, error)
+	// Baseline returns the baseline of a form, false when it has none
+	Baseline(ctx context.Context, formID string) (Baseline, bool, error)
+	SetBaseline(ctx context.Context, formID string, baseline Baseline) error
+	// Throttled lists the forms throttled
+	Throttled(ctx context.Context) ([]string, error)
+	SetThrottled(ctx context.Context, formID string, throttled bool) error
+}
+
+// RedisStore is a Store in Redis. Counts expire once they are older than
+// the lookback of the detector and baselines once their form has seen no
+// submission for two baseline periods.
+type RedisStore struct {
+	client      redis.UniversalClient
+	prefix      string
+	countTTL    time.Duration
+	baselineTTL time.Duration
+}
+
+// NewRedisStore creates a store of keys prefixed with prefix, keeping
+// counts for countTTL and baselines for baselineTTL
+func NewRedisStore(client redis.UniversalClient, prefix string, countTTL, baselineTTL time.Duration) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix, countTTL: countTTL, baselineTTL: baselineTTL}
+}
+
+func (s *RedisStore) countKey(formID string, minute int64) string {
+	return s.prefix + "count:" + formID + ":" + strconv.FormatInt(minute, 10)
+}
+
+func (s *RedisStore) baselineKey(formID string) string {
+	return s.prefix + "baseline:" + formID
+}
+
+func (s *RedisStore) throttledKey() string {
+	return s.prefix + "throttled"
+}
+
+// Add counts n submissions to a form in a minute
+func (s *RedisStore) Add(ctx context.Context, formID string, minute, n int64) error {
+	key := s.countKey(formID, minute)
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.IncrBy(ctx, key, n)
+		pipe.Expire(ctx, key, s.countTTL)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to count submissions: %w", err)
+	}
+	return nil
+}
+
+// Counts returns the submissions of a form in each minute from first to last
+func (s *RedisStore) Counts(ctx context.Context, formID string, first, last int64) ([]int64, error) {
+	if last < first {
+		return nil, nil
+	}
+	keys := make([]string, 0, last-first+1)
+	for minute := first; minute <= last; minute++ {
+		keys = append(keys, s.countKey(formID, minute))
+	}
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read submission counts: %w", err)
+	}
+
+	counts := make([]int64, len(values))
+	for i, value := range values {
+		if text, ok := value.(string); ok {
+			counts[i], _ = strconv.ParseInt(text, 10, 64)
+		}
+	}
+	return counts, nil
+}
+
+// Baseline returns the baseline of a form, false when it has none
+func (s *RedisStore) Baseline(ctx context.Context, formID string) (Baseline, bool, error) {
+	fields, err := s.client.HGetAll(ctx, s.baselineKey(formID)).Result()
+	if err != nil {
+		return Baseline{}, false, fmt.Errorf("failed to read baseline: %w", err)
+	}
+	value, valueErr := strconv.ParseFloat(fields["value"], 64)
+	minute, minuteErr := strconv.ParseInt(fields["minute"], 10, 64)
+	if valueErr != nil || minuteErr != nil {
+		return Baseline{}, false, nil
+	}
+	return Baseline{Value: value, Minute: minute}, true, nil
+}
+
+// SetBaseline stores the baseline of a form
+func (s *RedisStore) SetBaseline(ctx context.Context, formID string, baseline Baseline) error {
+	key := s.baselineKey(formID)
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, "value", strconv.FormatFloat(baseline.Value, 'g', -1, 64), "minute", baseline.Minute)
+		pipe.Expire(ctx, key, s.baselineTTL)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store baseline: %w", err)
+	}
+	return nil
+}
+
+// Throttled lists the forms throttled
+func (s *RedisStore) Throttled(ctx context.Context) ([]string, error) {
+	forms, err := s.client.SMembers(ctx, s.throttledKey()).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("failed to list throttled forms: %w", err)
+	}
+	return forms, nil
+}
+
+// SetThrottled adds a form to, or removes it from, the forms throttled
+func (s *RedisStore) SetThrottled(ctx context.Context, formID string, throttled bool) error {
+	var err error
+	if throttled {
+		err = s.client.SAdd(ctx, s.throttledKey(), formID).Err()
+	} else {
+		err = s.client.SRem(ctx, s.throttledKey(), formID).Err()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record throttling: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,318 @@
+// Package audit keeps the audit log: an append-only record of who did what
+// across the services. Services publish audit.<action> events to the audit
+// topic; a Sink appends them to the audit_log table of the event store
+// database, where each entry carries a hash of itself and of the entry
+// before it, so that changing, removing or reordering entries breaks the
+// chain and is found by Verify.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/projections"
+)
+
+// EventTypePrefix prefixes the type of audit events; the rest is the action
+const EventTypePrefix = "audit."
+
+// sinkName labels the metrics of the audit sink
+const sinkName = "audit_log"
+
+// ErrInvalidFilter is returned for audit queries that can't be run
+var ErrInvalidFilter = errors.New("invalid audit filter")
+
+// Entry is one entry of the audit log
+type Entry struct {
+	// Sequence numbers the entries from 1 without gaps
+	Sequence int64  `json:"sequence"`
+	EventID  string `json:"event_id"`
+	// Actor is the user or API key that acted
+	Actor        string `json:"actor"`
+	Action       string `json:"action" example:"form.published"`
+	ResourceType string `json:"resource_type" example:"form"`
+	ResourceID   string `json:"resource_id"`
+	// Before and After summarize the resource around the action
+	Before        json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After         json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+	IP            string          `json:"ip,omitempty"`
+	CorrelationID string          `json:"correlation_id,omitempty"`
+	Source        string          `json:"source"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	// PrevHash is the hash of the entry before, empty for the first
+	PrevHash string `json:"prev_hash"`
+	Hash     string `json:"hash"`
+}
+
+// EntryFromMessage converts a consumed audit event into an entry, not yet
+// chained
+func EntryFromMessage(message *kafka.Message) (*Entry, error) {
+	if message.ID == "" {
+		return nil, fmt.Errorf("event id is required")
+	}
+	action := strings.TrimPrefix(message.EventType, EventTypePrefix)
+	if action == message.EventType || action == "" {
+		return nil, fmt.Errorf("event type %q is not an audit event", message.EventType)
+	}
+
+	var data struct {
+		Actor         string          `json:"actor"`
+		ResourceType  string          `json:"resource_type"`
+		ResourceID    string          `json:"resource_id"`
+		Before        json.RawMessage `json:"before"`
+		After         json.RawMessage `json:"after"`
+		IP            string          `json:"ip"`
+		CorrelationID string          `json:"correlation_id"`
+		OccurredAt    time.Time       `json:"occurred_at"`
+	}
+	encoded, err := json.Marshal(message.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode event data: %w", err)
+	}
+	if err := json.Unmarshal(encoded, &data); err != nil {
+		return nil, fmt.Errorf("invalid audit event data: %w", err)
+	}
+	if data.Actor == "" {
+		return nil, fmt.Errorf("actor is required")
+	}
+
+	entry := &Entry{
+		EventID:       message.ID,
+		Actor:         data.Actor,
+		Action:        action,
+		ResourceType:  data.ResourceType,
+		ResourceID:    data.ResourceID,
+		Before:        summary(data.Before),
+		After:         summary(data.After),
+		IP:            data.IP,
+		CorrelationID: data.CorrelationID,
+		Source:        message.Source,
+		OccurredAt:    data.OccurredAt,
+	}
+	if entry.CorrelationID == "" {
+		entry.CorrelationID = message.CorrelationID
+	}
+	if entry.OccurredAt.IsZero() {
+		entry.OccurredAt = message.Metadata.Timestamp
+	}
+	if entry.OccurredAt.IsZero() {
+		entry.OccurredAt = time.Now()
+	}
+	// PostgreSQL keeps microseconds, and the hash must survive the round trip
+	entry.OccurredAt = entry.OccurredAt.UTC().Truncate(time.Microsecond)
+	return entry, nil
+}
+
+// summary drops JSON nulls, so an absent and a null summary hash alike
+func summary(raw json.RawMessage) json.RawMessage {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	return raw
+}
+
+// chain links the entry after the one with sequence and hash
+func (e *Entry) chain(sequence int64, prevHash string) {
+	e.Sequence = sequence + 1
+	e.PrevHash = prevHash
+	e.Hash = e.computeHash()
+}
+
+// computeHash hashes every field of the entry but the hash itself. Each
+// field is length prefixed, so moving text between fields changes the hash.
+func (e *Entry) computeHash() string {
+	h := sha256.New()
+	for _, field := range []string{
+		strconv.FormatInt(e.Sequence, 10), e.EventID, e.Actor, e.Action,
+		e.ResourceType, e.ResourceID, string(e.Before), string(e.After),
+		e.IP, e.CorrelationID, e.Source,
+		e.OccurredAt.UTC().Format(time.RFC3339Nano), e.PrevHash,
+	} {
+		fmt.Fprintf(h, "%d:%s", len(field), field)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Verification is the outcome of walking the chain
+type Verification struct {
+	Valid bool `json:"valid"`
+	// Checked is the number of entries walked, up to the first broken link
+	Checked      int64  `json:"checked"`
+	HeadSequence int64  `json:"head_sequence"`
+	HeadHash     string `json:"head_hash,omitempty"`
+	// FirstBroken is the first entry that does not link up
+	FirstBroken *Break    `json:"first_broken,omitempty"`
+	VerifiedAt  time.Time `json:"verified_at"`
+}
+
+// Break is a broken link of the chain
+type Break struct {
+	Sequence int64  `json:"sequence"`
+	EventID  string `json:"event_id,omitempty"`
+	Reason   string `json:"reason" example:"hash does not match the entry"`
+}
+
+// verifier walks the chain an entry at a time, from the first
+type verifier struct {
+	result Verification
+}
+
+// check checks that entry follows the entries checked so far, returning
+// false at the first broken link
+func (v *verifier) check(entry *Entry) bool {
+	var reason string
+	switch {
+	case entry.Sequence == v.result.HeadSequence+2:
+		reason = fmt.Sprintf("entry %d is missing", v.result.HeadSequence+1)
+	case entry.Sequence != v.result.HeadSequence+1:
+		reason = fmt.Sprintf("entries %d to %d are missing", v.result.HeadSequence+1, entry.Sequence-1)
+	case entry.PrevHash != v.result.HeadHash:
+		reason = "previous hash does not match the entry before"
+	case entry.Hash != entry.computeHash():
+		reason = "hash does not match the entry"
+	}
+	if reason != "" {
+		v.result.FirstBroken = &Break{Sequence: entry.Sequence, EventID: entry.EventID, Reason: reason}
+		return false
+	}
+
+	v.result.Checked++
+	v.result.HeadSequence = entry.Sequence
+	v.result.HeadHash = entry.Hash
+	return true
+}
+
+// Filter selects audit entries, newest first
+type Filter struct {
+	Actor string
+	// Resource is a resource type, or a type and ID as type:id
+	Resource string
+	From     *time.Time
+	To       *time.Time
+	// Limit is 50 by default and at most 500
+	Limit int
+	// Before is the sequence the page ends before, 0 for the newest entries
+	Before int64
+}
+
+// Page is a page of audit entries
+type Page struct {
+	Entries []Entry `json:"entries"`
+	// NextCursor is passed as before for the following page, empty on the last
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// normalize applies the default limit and checks the filter
+func (f Filter) normalize() (Filter, error) {
+	if f.Limit == 0 {
+		f.Limit = 50
+	}
+	if f.Limit < 0 || f.Limit > 500 {
+		return f, fmt.Errorf("%w: limit must be between 1 and 500", ErrInvalidFilter)
+	}
+	if f.Before < 0 {
+		return f, fmt.Errorf("%w: invalid cursor", ErrInvalidFilter)
+	}
+	if f.From != nil && f.To != nil && f.To.Before(*f.From) {
+		return f, fmt.Errorf("%w: to is before from", ErrInvalidFilter)
+	}
+	return f, nil
+}
+
+// Appender appends entries to the audit log
+type Appender interface {
+	// Append chains and stores entries in order, skipping any whose event
+	// is already logged, and returns the number appended
+	Append(ctx context.Context, entries []Entry) (int64, error)
+}
+
+// Reader reads the audit log
+type Reader interface {
+	Query(ctx context.Context, filter Filter) (*Page, error)
+	Verify(ctx context.Context) (*Verification, error)
+}
+
+// Sink consumes audit events and appends them to the audit log. It
+// implements kafka.BatchConsumerHandler.
+type Sink struct {
+	log     Appender
+	metrics *projections.Metrics
+	logger  *zap.Logger
+	topics  []string
+	groupID string
+}
+
+// NewSink creates the sink appending the events of cfg.Topics to log
+func NewSink(cfg config.AuditConfig, log Appender, metrics *projections.Metrics, logger *zap.Logger) *Sink {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Sink{
+		log:     log,
+		metrics: metrics,
+		logger:  logger,
+		topics:  cfg.Topics,
+		groupID: cfg.GroupID,
+	}
+}
+
+// GetTopics returns the audit topics
+func (s *Sink) GetTopics() []string {
+	return s.topics
+}
+
+// GetGroupID returns the consumer group the sink commits offsets in
+func (s *Sink) GetGroupID() string {
+	return s.groupID
+}
+
+// HandleBatch appends a batch of audit events in a single transaction.
+// Invalid events are logged and dropped; a failed append fails the batch so
+// it is redelivered, and redelivered events are skipped by their ID.
+func (s *Sink) HandleBatch(ctx context.Context, messages []*kafka.Message) error {
+	start := time.Now()
+
+	var newest time.Time
+	entries := make([]Entry, 0, len(messages))
+	for _, message := range messages {
+		entry, err := EntryFromMessage(message)
+		if err != nil {
+			s.logger.Warn("Dropping invalid audit event",
+				zap.String("event_id", message.ID),
+				zap.String("event_type", message.EventType),
+				zap.Error(err))
+			s.metrics.Events.WithLabelValues(sinkName, "invalid").Inc()
+			continue
+		}
+		entries = append(entries, *entry)
+		if entry.OccurredAt.After(newest) {
+			newest = entry.OccurredAt
+		}
+	}
+
+	appended, err := s.log.Append(ctx, entries)
+	if err != nil {
+		s.metrics.Events.WithLabelValues(sinkName, "failed").Add(float64(len(entries)))
+		return fmt.Errorf("failed to append to the audit log: %w", err)
+	}
+
+	s.metrics.Events.WithLabelValues(sinkName, "projected").Add(float64(len(entries)))
+	s.metrics.RowsWritten.WithLabelValues(sinkName).Add(float64(appended))
+	s.metrics.DuplicateRows.WithLabelValues(sinkName).Add(float64(int64(len(entries)) - appended))
+	s.metrics.BatchDuration.WithLabelValues(sinkName).Observe(time.Since(start).Seconds())
+	if !newest.IsZero() {
+		s.metrics.Lag.WithLabelValues(sinkName).Set(time.Since(newest).Seconds())
+	}
+	return nil
+}
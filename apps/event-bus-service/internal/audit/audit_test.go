@@ -0,0 +1,276 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/projections"
+)
+
+// memoryLog chains entries in memory the way PostgresStore does
+type memoryLog struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+func (l *memoryLog) Append(_ context.Context, entries []Entry) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	logged := make(map[string]bool)
+	for _, entry := range l.entries {
+		logged[entry.EventID] = true
+	}
+	var appended int64
+	for _, entry := range entries {
+		if logged[entry.EventID] {
+			continue
+		}
+		logged[entry.EventID] = true
+
+		var sequence int64
+		var head string
+		if n := len(l.entries); n > 0 {
+			sequence, head = l.entries[n-1].Sequence, l.entries[n-1].Hash
+		}
+		entry.chain(sequence, head)
+		l.entries = append(l.entries, entry)
+		appended++
+	}
+	return appended, nil
+}
+
+func verify(entries []Entry) Verification {
+	var v verifier
+	for i := range entries {
+		if !v.check(&entries[i]) {
+			return v.result
+		}
+	}
+	v.result.Valid = true
+	return v.result
+}
+
+func auditMessage(id, action, actor string) *kafka.Message {
+	return &kafka.Message{
+		ID:        id,
+		EventType: EventTypePrefix + action,
+		Source:    "form-service",
+		Data: map[string]interface{}{
+			"actor":         actor,
+			"resource_type": "form",
+			"resource_id":   "form-1",
+			"before":        map[string]interface{}{"status": "draft"},
+			"after":         map[string]interface{}{"status": "published"},
+			"ip":            "203.0.113.7",
+		},
+		CorrelationID: "req-1",
+		Metadata:      kafka.MessageMetadata{Timestamp: time.Date(2026, 5, 1, 12, 0, 0, 123456789, time.UTC)},
+	}
+}
+
+func TestEntryFromMessage(t *testing.T) {
+	entry, err := EntryFromMessage(auditMessage("evt_1", "form.published", "user-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Action != "form.published" || entry.Actor != "user-1" || entry.ResourceID != "form-1" {
+		t.Errorf("entry = %+v", entry)
+	}
+	if entry.CorrelationID != "req-1" {
+		t.Errorf("correlation ID = %q, want the one of the message", entry.CorrelationID)
+	}
+	if string(entry.After) != `{"status":"published"}` {
+		t.Errorf("after = %s", entry.After)
+	}
+	if want := time.Date(2026, 5, 1, 12, 0, 0, 123456000, time.UTC); !entry.OccurredAt.Equal(want) {
+		t.Errorf("occurred at %v, want the message time truncated to %v", entry.OccurredAt, want)
+	}
+
+	invalid := []*kafka.Message{
+		auditMessage("", "form.published", "user-1"),
+		auditMessage("evt_2", "form.published", ""),
+		{ID: "evt_3", EventType: "form.published", Data: map[string]interface{}{"actor": "user-1"}},
+		{ID: "evt_4", EventType: EventTypePrefix, Data: map[string]interface{}{"actor": "user-1"}},
+	}
+	for _, message := range invalid {
+		if _, err := EntryFromMessage(message); err == nil {
+			t.Errorf("EntryFromMessage(%s %q) accepted an invalid event", message.ID, message.EventType)
+		}
+	}
+}
+
+func TestVerifyFindsFirstBrokenLink(t *testing.T) {
+	build := func() []Entry {
+		log := &memoryLog{}
+		for i := 1; i <= 5; i++ {
+			entry, err := EntryFromMessage(auditMessage(fmt.Sprintf("evt_%d", i), "form.deleted", "user-1"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := log.Append(context.Background(), []Entry{*entry}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return log.entries
+	}
+
+	if result := verify(build()); !result.Valid || result.Checked != 5 || result.HeadSequence != 5 {
+		t.Fatalf("untouched chain = %+v, want 5 valid entries", result)
+	}
+
+	tests := []struct {
+		name   string
+		tamper func([]Entry) []Entry
+		want   Break
+	}{
+		{"altered", func(e []Entry) []Entry { e[2].Actor = "user-2"; return e },
+			Break{Sequence: 3, EventID: "evt_3", Reason: "hash does not match the entry"}},
+		{"rehashed", func(e []Entry) []Entry { e[2].Actor = "user-2"; e[2].Hash = e[2].computeHash(); return e },
+			Break{Sequence: 4, EventID: "evt_4", Reason: "previous hash does not match the entry before"}},
+		{"removed", func(e []Entry) []Entry { return append(e[:1], e[3:]...) },
+			Break{Sequence: 4, EventID: "evt_4", Reason: "entries 2 to 3 are missing"}},
+		{"swapped", func(e []Entry) []Entry { e[1], e[2] = e[2], e[1]; return e },
+			Break{Sequence: 3, EventID: "evt_3", Reason: "entry 2 is missing"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := verify(tt.tamper(build()))
+			if result.Valid || result.FirstBroken == nil || *result.FirstBroken != tt.want {
+				t.Errorf("verification = %+v, broken at %+v, want %+v", result, result.FirstBroken, tt.want)
+			}
+		})
+	}
+}
+
+func TestSinkAppendsEventsOnce(t *testing.T) {
+	log := &memoryLog{}
+	metrics := projections.NewMetrics(prometheus.NewRegistry())
+	sink := NewSink(config.AuditConfig{Topics: []string{"audit-log"}, GroupID: "event-bus-audit"}, log, metrics, nil)
+
+	messages := []*kafka.Message{
+		auditMessage("evt_1", "form.published", "user-1"),
+		auditMessage("evt_2", "apikey.revoked", "admin-1"),
+		{ID: "evt_3", EventType: "form.created", Data: map[string]interface{}{}},
+	}
+	for i := 0; i < 2; i++ {
+		if err := sink.HandleBatch(context.Background(), messages); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(log.entries) != 2 || log.entries[1].Action != "apikey.revoked" {
+		t.Fatalf("appended %+v, want evt_1 and evt_2", log.entries)
+	}
+	if result := verify(log.entries); !result.Valid {
+		t.Errorf("appended chain is broken at %+v", result.FirstBroken)
+	}
+	if got := testutil.ToFloat64(metrics.DuplicateRows.WithLabelValues(sinkName)); got != 2 {
+		t.Errorf("duplicates = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(metrics.Events.WithLabelValues(sinkName, "invalid")); got != 2 {
+		t.Errorf("invalid events = %v, want 2", got)
+	}
+}
+
+func TestFilterNormalize(t *testing.T) {
+	from := time.Date(2026, 5, 2, 0, 0, 0, 0, time.UTC)
+	to := from.Add(-time.Hour)
+
+	if filter, err := (Filter{}).normalize(); err != nil || filter.Limit != 50 {
+		t.Errorf("default filter = %+v, %v, want a limit of 50", filter, err)
+	}
+	for _, filter := range []Filter{{Limit: 501}, {Limit: -1}, {Before: -1}, {From: &from, To: &to}} {
+		if _, err := filter.normalize(); !errors.Is(err, ErrInvalidFilter) {
+			t.Errorf("normalize(%+v) err = %v, want ErrInvalidFilter", filter, err)
+		}
+	}
+}
+
+// TestPostgresStore appends, queries and verifies the audit log against a
+// real database when EVENTBUS_TEST_DATABASE_URL is set
+func TestPostgresStore(t *testing.T) {
+	dsn := os.Getenv("EVENTBUS_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("EVENTBUS_TEST_DATABASE_URL not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if _, err := db.ExecContext(ctx, "DROP TABLE IF EXISTS audit_log"); err != nil {
+		t.Fatalf("failed to reset table: %v", err)
+	}
+	store := NewPostgresStore(db)
+	if err := store.EnsureSchema(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var entries []Entry
+	for i, actor := range []string{"user-1", "user-2", "user-1"} {
+		entry, err := EntryFromMessage(auditMessage(fmt.Sprintf("evt_%d", i+1), "form.published", actor))
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, *entry)
+	}
+	if appended, err := store.Append(ctx, entries[:2]); err != nil || appended != 2 {
+		t.Fatalf("appended %d entries: %v", appended, err)
+	}
+	if appended, err := store.Append(ctx, entries); err != nil || appended != 1 {
+		t.Fatalf("appended %d entries on redelivery: %v", appended, err)
+	}
+
+	page, err := store.Query(ctx, Filter{Actor: "user-1", Resource: "form:form-1", Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Entries) != 1 || page.Entries[0].EventID != "evt_3" || page.NextCursor != "3" {
+		t.Fatalf("first page = %+v", page)
+	}
+	page, err = store.Query(ctx, Filter{Actor: "user-1", Limit: 1, Before: 3})
+	if err != nil || len(page.Entries) != 1 || page.Entries[0].EventID != "evt_1" {
+		t.Fatalf("second page = %+v, %v", page, err)
+	}
+	if string(page.Entries[0].Before) != `{"status":"draft"}` {
+		t.Errorf("before = %s", page.Entries[0].Before)
+	}
+
+	result, err := store.Verify(ctx)
+	if err != nil || !result.Valid || result.Checked != 3 {
+		t.Fatalf("verification = %+v, %v, want 3 valid entries", result, err)
+	}
+
+	if _, err := db.ExecContext(ctx, "UPDATE audit_log SET actor = 'user-3' WHERE sequence = 2"); err == nil || !strings.Contains(err.Error(), "append-only") {
+		t.Errorf("update err = %v, want the append-only trigger to reject it", err)
+	}
+
+	// Tampering around the trigger is found by the chain
+	if _, err := db.ExecContext(ctx, "ALTER TABLE audit_log DISABLE TRIGGER audit_log_append_only"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.ExecContext(ctx, "UPDATE audit_log SET actor = 'user-3' WHERE sequence = 2"); err != nil {
+		t.Fatal(err)
+	}
+	result, err = store.Verify(ctx)
+	if err != nil || result.Valid || result.FirstBroken == nil || result.FirstBroken.Sequence != 2 {
+		t.Errorf("verification after tampering = %+v, %v, want entry 2 broken", result, err)
+	}
+}
@@ -0,0 +1,296 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Schema creates the audit_log table. Updates, deletes and truncation are
+// rejected by triggers; the hash chain finds changes made around them.
+const Schema = `
+CREATE TABLE IF NOT EXISTS audit_log (
+    sequence BIGINT PRIMARY KEY,
+    event_id VARCHAR(255) NOT NULL UNIQUE,
+    actor VARCHAR(255) NOT NULL,
+    action VARCHAR(255) NOT NULL,
+    resource_type VARCHAR(255) NOT NULL DEFAULT '',
+    resource_id VARCHAR(255) NOT NULL DEFAULT '',
+    before_summary TEXT,
+    after_summary TEXT,
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    correlation_id VARCHAR(255) NOT NULL DEFAULT '',
+    source VARCHAR(255) NOT NULL DEFAULT '',
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    recorded_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    prev_hash VARCHAR(64) NOT NULL,
+    hash VARCHAR(64) NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor, sequence);
+CREATE INDEX IF NOT EXISTS idx_audit_log_resource ON audit_log(resource_type, resource_id, sequence);
+CREATE INDEX IF NOT EXISTS idx_audit_log_occurred_at ON audit_log(occurred_at);
+
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+DROP TRIGGER IF EXISTS audit_log_append_only ON audit_log;
+CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+DROP TRIGGER IF EXISTS audit_log_no_truncate ON audit_log;
+CREATE TRIGGER audit_log_no_truncate BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
+`
+
+// entryColumns are the columns written and read for each entry, in the order
+// of the statement placeholders
+var entryColumns = []string{
+	"sequence", "event_id", "actor", "action", "resource_type", "resource_id",
+	"before_summary", "after_summary", "ip", "correlation_id", "source",
+	"occurred_at", "prev_hash", "hash",
+}
+
+// appendLockKey is the advisory lock serializing appends across replicas, so
+// each entry chains onto the one appended before it
+const appendLockKey = 0x6175646974 // "audit"
+
+// verifyPageSize bounds the entries read at once while verifying
+const verifyPageSize = 1000
+
+// PostgresStore keeps the audit log in the audit_log table
+type PostgresStore struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+// NewPostgresStore creates a store writing through db
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db, now: time.Now}
+}
+
+// EnsureSchema creates the audit_log table, its indexes and triggers if missing
+func (s *PostgresStore) EnsureSchema(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, Schema); err != nil {
+		return fmt.Errorf("failed to create audit_log table: %w", err)
+	}
+	return nil
+}
+
+// Append chains entries onto the head of the log and inserts them in one
+// transaction, holding the append lock so concurrent appends can't fork
+// the chain
+func (s *PostgresStore) Append(ctx context.Context, entries []Entry) (int64, error) {
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", appendLockKey); err != nil {
+		return 0, fmt.Errorf("failed to lock the audit log: %w", err)
+	}
+
+	ids := make([]string, len(entries))
+	for i := range entries {
+		ids[i] = entries[i].EventID
+	}
+	logged, err := s.loggedEvents(ctx, tx, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		sequence int64
+		head     string
+	)
+	err = tx.QueryRowContext(ctx, "SELECT sequence, hash FROM audit_log ORDER BY sequence DESC LIMIT 1").Scan(&sequence, &head)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, fmt.Errorf("failed to read the head of the audit log: %w", err)
+	}
+
+	var appended int64
+	for i := range entries {
+		entry := entries[i]
+		if logged[entry.EventID] {
+			continue
+		}
+		logged[entry.EventID] = true
+
+		entry.chain(sequence, head)
+		if err := insertEntry(ctx, tx, &entry); err != nil {
+			return 0, err
+		}
+		sequence, head = entry.Sequence, entry.Hash
+		appended++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit audit entries: %w", err)
+	}
+	return appended, nil
+}
+
+// loggedEvents returns which of ids are already logged
+func (s *PostgresStore) loggedEvents(ctx context.Context, tx *sql.Tx, ids []string) (map[string]bool, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT event_id FROM audit_log WHERE event_id = ANY($1)", pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up logged events: %w", err)
+	}
+	defer rows.Close()
+
+	logged := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		logged[id] = true
+	}
+	return logged, rows.Err()
+}
+
+func insertEntry(ctx context.Context, tx *sql.Tx, entry *Entry) error {
+	placeholders := make([]string, len(entryColumns))
+	for i := range placeholders {
+		placeholders[i] = "$" + strconv.Itoa(i+1)
+	}
+	query := "INSERT INTO audit_log (" + strings.Join(entryColumns, ", ") + ") VALUES (" + strings.Join(placeholders, ", ") + ")"
+
+	_, err := tx.ExecContext(ctx, query,
+		entry.Sequence, entry.EventID, entry.Actor, entry.Action, entry.ResourceType, entry.ResourceID,
+		nullableText(entry.Before), nullableText(entry.After), entry.IP, entry.CorrelationID, entry.Source,
+		entry.OccurredAt, entry.PrevHash, entry.Hash)
+	if err != nil {
+		return fmt.Errorf("failed to insert audit entry %s: %w", entry.EventID, err)
+	}
+	return nil
+}
+
+// Query returns a page of the entries matching filter, newest first
+func (s *PostgresStore) Query(ctx context.Context, filter Filter) (*Page, error) {
+	filter, err := filter.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	where := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.Actor != "" {
+		where("actor = $%d", filter.Actor)
+	}
+	if filter.Resource != "" {
+		resourceType, resourceID, hasID := strings.Cut(filter.Resource, ":")
+		where("resource_type = $%d", resourceType)
+		if hasID {
+			where("resource_id = $%d", resourceID)
+		}
+	}
+	if filter.From != nil {
+		where("occurred_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		where("occurred_at < $%d", *filter.To)
+	}
+	if filter.Before > 0 {
+		where("sequence < $%d", filter.Before)
+	}
+
+	query := "SELECT " + strings.Join(entryColumns, ", ") + " FROM audit_log"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY sequence DESC LIMIT $%d", len(args))
+
+	entries, err := s.queryEntries(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the audit log: %w", err)
+	}
+
+	page := &Page{Entries: entries}
+	if len(entries) == filter.Limit {
+		page.NextCursor = strconv.FormatInt(entries[len(entries)-1].Sequence, 10)
+	}
+	return page, nil
+}
+
+// Verify walks the whole chain from the first entry, reporting the first
+// entry that is missing, out of place or altered
+func (s *PostgresStore) Verify(ctx context.Context) (*Verification, error) {
+	var v verifier
+	for {
+		entries, err := s.queryEntries(ctx,
+			"SELECT "+strings.Join(entryColumns, ", ")+" FROM audit_log WHERE sequence > $1 ORDER BY sequence LIMIT $2",
+			v.result.HeadSequence, verifyPageSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the audit log: %w", err)
+		}
+		for i := range entries {
+			if !v.check(&entries[i]) {
+				v.result.VerifiedAt = s.now().UTC()
+				return &v.result, nil
+			}
+		}
+		if len(entries) < verifyPageSize {
+			break
+		}
+	}
+
+	v.result.Valid = true
+	v.result.VerifiedAt = s.now().UTC()
+	return &v.result, nil
+}
+
+func (s *PostgresStore) queryEntries(ctx context.Context, query string, args ...interface{}) ([]Entry, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		var (
+			entry         Entry
+			before, after sql.NullString
+		)
+		err := rows.Scan(&entry.Sequence, &entry.EventID, &entry.Actor, &entry.Action,
+			&entry.ResourceType, &entry.ResourceID, &before, &after, &entry.IP,
+			&entry.CorrelationID, &entry.Source, &entry.OccurredAt, &entry.PrevHash, &entry.Hash)
+		if err != nil {
+			return nil, err
+		}
+		if before.Valid {
+			entry.Before = []byte(before.String)
+		}
+		if after.Valid {
+			entry.After = []byte(after.String)
+		}
+		entry.OccurredAt = entry.OccurredAt.UTC()
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func nullableText(raw []byte) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
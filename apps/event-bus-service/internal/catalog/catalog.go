@@ -0,0 +1,348 @@
+// Package catalog keeps the catalog of the event types: the services that
+// register as their producers and consumers, the latest version of their
+// schemas and when they were last seen in traffic, with a sample payload.
+// It is the source of the developer portal, so the JSON of EventType and
+// Registration is part of the API.
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+)
+
+// Sources of the registrations
+const (
+	SourceAPI    = "api"
+	SourceConfig = "config"
+)
+
+// eventTypeName matches the names of event types, such as
+// form.response.created
+var eventTypeName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,254}$`)
+
+// ErrInvalidRegistration is returned for registrations that can't be kept
+var ErrInvalidRegistration = errors.New("invalid registration")
+
+// Registration declares the event types a service produces and consumes.
+// Registering again is the heartbeat of a service.
+type Registration struct {
+	Service  string   `json:"service" example:"form-service"`
+	Produces []string `json:"produces" example:"form.published"`
+	Consumes []string `json:"consumes" example:"form.response.created"`
+	// SchemaRef locates the schemas of the events the service produces
+	SchemaRef string `json:"schema_ref,omitempty" example:"https://github.com/Mir00r/X-Form-Backend/tree/main/schemas/form-service"`
+	Team      string `json:"team,omitempty" example:"forms"`
+	// Source is api for services registered through the API, config for
+	// those declared in event_processing.catalog.registrations
+	Source       string    `json:"source" example:"api"`
+	RegisteredAt time.Time `json:"registered_at"`
+	HeartbeatAt  time.Time `json:"heartbeat_at"`
+}
+
+// Party is a service producing or consuming an event type
+type Party struct {
+	Service     string    `json:"service" example:"form-service"`
+	Team        string    `json:"team,omitempty" example:"forms"`
+	SchemaRef   string    `json:"schema_ref,omitempty"`
+	HeartbeatAt time.Time `json:"heartbeat_at"`
+	// Stale flags registrations without a heartbeat nor traffic of their
+	// event types for event_processing.catalog.stale_after
+	Stale bool `json:"stale"`
+}
+
+// EventType is an event type of the catalog, registered or seen in traffic
+type EventType struct {
+	EventType string  `json:"event_type" example:"form.published"`
+	Producers []Party `json:"producers"`
+	Consumers []Party `json:"consumers"`
+	// SchemaVersion is the latest version registered in the schema
+	// registry, null without one or without schema compatibility
+	SchemaVersion *int `json:"schema_version" example:"3"`
+	// SamplePayload is the data of an event recently seen, null until one
+	// is
+	SamplePayload json.RawMessage `json:"sample_payload" swaggertype:"object"`
+	// LastSeen is when an event of the type was last published or
+	// consumed, null until one is
+	LastSeen *time.Time `json:"last_seen"`
+}
+
+// Report is the catalog served by GET /catalog/event-types
+type Report struct {
+	// EventTypes are sorted by name
+	EventTypes []EventType `json:"event_types"`
+	// StaleAfter is how long registrations go without a heartbeat nor
+	// traffic before they are flagged stale
+	StaleAfter  string    `json:"stale_after" example:"720h0m0s"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// Sighting is the last time an event type was seen in traffic
+type Sighting struct {
+	EventType string
+	LastSeen  time.Time
+	// Sample is nil when no event was sampled
+	Sample json.RawMessage
+}
+
+// Store keeps the registrations and sightings, shared by the replicas
+type Store interface {
+	// Register creates or refreshes the registration of a service, keeping
+	// its RegisteredAt, and returns it as stored
+	Register(ctx context.Context, registration Registration) (*Registration, error)
+	Registrations(ctx context.Context) ([]Registration, error)
+	// RecordSightings moves the last seen times forward, and replaces the
+	// samples of the sightings carrying one
+	RecordSightings(ctx context.Context, sightings []Sighting) error
+	Sightings(ctx context.Context) ([]Sighting, error)
+}
+
+// SchemaVersions finds the latest schema version of event types
+type SchemaVersions interface {
+	LatestVersion(ctx context.Context, eventType string) (int, bool, error)
+}
+
+// Catalog registers services and assembles the catalog. Its Tracker
+// follows the event types in traffic.
+type Catalog struct {
+	cfg     config.CatalogConfig
+	store   Store
+	schemas SchemaVersions
+	tracker *Tracker
+	logger  *zap.Logger
+	now     func() time.Time
+}
+
+// New creates a catalog. schemas may be nil, leaving schema versions out.
+func New(cfg config.CatalogConfig, store Store, schemas SchemaVersions, logger *zap.Logger) *Catalog {
+	return &Catalog{
+		cfg:     cfg,
+		store:   store,
+		schemas: schemas,
+		tracker: NewTracker(cfg.SampleMaxBytes, cfg.MaxEventTypes),
+		logger:  logger,
+		now:     time.Now,
+	}
+}
+
+// Tracker returns the tracker to observe the traffic with
+func (c *Catalog) Tracker() *Tracker {
+	return c.tracker
+}
+
+// Register creates or refreshes the registration of a service
+func (c *Catalog) Register(ctx context.Context, registration Registration) (*Registration, error) {
+	if err := normalize(&registration); err != nil {
+		return nil, err
+	}
+	if registration.Source == "" {
+		registration.Source = SourceAPI
+	}
+	now := c.now().UTC()
+	registration.RegisteredAt, registration.HeartbeatAt = now, now
+	return c.store.Register(ctx, registration)
+}
+
+// normalize checks a registration and sorts its event types
+func normalize(registration *Registration) error {
+	registration.Service = strings.TrimSpace(registration.Service)
+	if registration.Service == "" || len(registration.Service) > 255 {
+		return fmt.Errorf("%w: service is required, of at most 255 characters", ErrInvalidRegistration)
+	}
+	if len(registration.Team) > 255 {
+		return fmt.Errorf("%w: team must be at most 255 characters", ErrInvalidRegistration)
+	}
+	if len(registration.Produces)+len(registration.Consumes) == 0 {
+		return fmt.Errorf("%w: the event types produced or consumed are required", ErrInvalidRegistration)
+	}
+	for _, types := range []*[]string{&registration.Produces, &registration.Consumes} {
+		normalized, err := normalizeTypes(*types)
+		if err != nil {
+			return err
+		}
+		*types = normalized
+	}
+	return nil
+}
+
+// normalizeTypes sorts event types and drops duplicates
+func normalizeTypes(types []string) ([]string, error) {
+	seen := make(map[string]bool, len(types))
+	normalized := make([]string, 0, len(types))
+	for _, eventType := range types {
+		eventType = strings.TrimSpace(eventType)
+		if !eventTypeName.MatchString(eventType) {
+			return nil, fmt.Errorf("%w: event type %q must be letters, digits, dots, dashes and underscores", ErrInvalidRegistration, eventType)
+		}
+		if !seen[eventType] {
+			seen[eventType] = true
+			normalized = append(normalized, eventType)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// RegisterConfigured registers the services declared in
+// event_processing.catalog.registrations, heartbeating them at each start
+func (c *Catalog) RegisterConfigured(ctx context.Context) error {
+	for _, declared := range c.cfg.Registrations {
+		registration := Registration{
+			Service:   declared.Service,
+			Produces:  declared.Produces,
+			Consumes:  declared.Consumes,
+			SchemaRef: declared.SchemaRef,
+			Team:      declared.Team,
+			Source:    SourceConfig,
+		}
+		if _, err := c.Register(ctx, registration); err != nil {
+			return fmt.Errorf("failed to register %s: %w", declared.Service, err)
+		}
+	}
+	return nil
+}
+
+// Report assembles the catalog from the registrations, the sightings and
+// the schema registry
+func (c *Catalog) Report(ctx context.Context) (*Report, error) {
+	registrations, err := c.store.Registrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sightings, err := c.store.Sightings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// The sightings not flushed yet are as recent as those stored
+	sightings = append(sightings, c.tracker.Pending()...)
+
+	now := c.now().UTC()
+	types := make(map[string]*EventType)
+	eventType := func(name string) *EventType {
+		if t, ok := types[name]; ok {
+			return t
+		}
+		t := &EventType{EventType: name, Producers: []Party{}, Consumers: []Party{}}
+		types[name] = t
+		return t
+	}
+	for _, sighting := range sightings {
+		t := eventType(sighting.EventType)
+		if t.LastSeen == nil || sighting.LastSeen.After(*t.LastSeen) {
+			lastSeen := sighting.LastSeen.UTC()
+			t.LastSeen = &lastSeen
+			if sighting.Sample != nil {
+				t.SamplePayload = sighting.Sample
+			}
+		} else if t.SamplePayload == nil {
+			t.SamplePayload = sighting.Sample
+		}
+	}
+	for _, registration := range registrations {
+		party := Party{
+			Service:     registration.Service,
+			Team:        registration.Team,
+			SchemaRef:   registration.SchemaRef,
+			HeartbeatAt: registration.HeartbeatAt.UTC(),
+			Stale:       c.stale(registration, types, now),
+		}
+		for _, name := range registration.Produces {
+			t := eventType(name)
+			t.Producers = append(t.Producers, party)
+		}
+		for _, name := range registration.Consumes {
+			t := eventType(name)
+			t.Consumers = append(t.Consumers, party)
+		}
+	}
+
+	report := &Report{EventTypes: make([]EventType, 0, len(types)), StaleAfter: c.cfg.StaleAfter.String(), GeneratedAt: now}
+	for _, t := range types {
+		sortParties(t.Producers)
+		sortParties(t.Consumers)
+		t.SchemaVersion = c.schemaVersion(ctx, t.EventType)
+		report.EventTypes = append(report.EventTypes, *t)
+	}
+	sort.Slice(report.EventTypes, func(i, j int) bool {
+		return report.EventTypes[i].EventType < report.EventTypes[j].EventType
+	})
+	return report, nil
+}
+
+// stale reports whether a registration has had neither a heartbeat nor
+// traffic of its event types for stale_after
+func (c *Catalog) stale(registration Registration, types map[string]*EventType, now time.Time) bool {
+	since := now.Add(-c.cfg.StaleAfter)
+	if registration.HeartbeatAt.After(since) {
+		return false
+	}
+	for _, names := range [][]string{registration.Produces, registration.Consumes} {
+		for _, name := range names {
+			if t, ok := types[name]; ok && t.LastSeen != nil && t.LastSeen.After(since) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// schemaVersion returns the latest schema version of an event type, nil
+// when unknown. The catalog is served without the versions the registry
+// fails to give.
+func (c *Catalog) schemaVersion(ctx context.Context, eventType string) *int {
+	if c.schemas == nil {
+		return nil
+	}
+	version, ok, err := c.schemas.LatestVersion(ctx, eventType)
+	if err != nil {
+		c.logger.Debug("Failed to read the schema version of a catalog event type",
+			zap.String("event_type", eventType), zap.Error(err))
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+	return &version
+}
+
+func sortParties(parties []Party) {
+	sort.Slice(parties, func(i, j int) bool { return parties[i].Service < parties[j].Service })
+}
+
+// Run writes the sightings of the tracker to the store every flush
+// interval, until ctx is done
+func (c *Catalog) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.Flush(ctx)
+		}
+	}
+}
+
+// Flush writes the sightings since the last flush to the store. Sightings
+// that fail to be written are tried again at the next flush.
+func (c *Catalog) Flush(ctx context.Context) {
+	sightings := c.tracker.Pending()
+	if len(sightings) == 0 {
+		return
+	}
+	if err := c.store.RecordSightings(ctx, sightings); err != nil {
+		c.logger.Warn("Failed to record the event types seen in traffic", zap.Int("event_types", len(sightings)), zap.Error(err))
+		return
+	}
+	c.tracker.Flushed(sightings)
+}
@@ -0,0 +1,398 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+)
+
+// memoryStore keeps the catalog in memory, as PostgresStore does
+type memoryStore struct {
+	mu            sync.Mutex
+	registrations map[string]Registration
+	sightings     map[string]Sighting
+	failSightings bool
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{registrations: make(map[string]Registration), sightings: make(map[string]Sighting)}
+}
+
+func (s *memoryStore) Register(_ context.Context, registration Registration) (*Registration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.registrations[registration.Service]; ok {
+		registration.RegisteredAt = existing.RegisteredAt
+	}
+	s.registrations[registration.Service] = registration
+	return &registration, nil
+}
+
+func (s *memoryStore) Registrations(context.Context) ([]Registration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	registrations := []Registration{}
+	for _, registration := range s.registrations {
+		registrations = append(registrations, registration)
+	}
+	sort.Slice(registrations, func(i, j int) bool { return registrations[i].Service < registrations[j].Service })
+	return registrations, nil
+}
+
+func (s *memoryStore) RecordSightings(_ context.Context, sightings []Sighting) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failSightings {
+		return errors.New("database unavailable")
+	}
+	for _, sighting := range sightings {
+		existing, ok := s.sightings[sighting.EventType]
+		if ok && existing.LastSeen.After(sighting.LastSeen) {
+			sighting.LastSeen = existing.LastSeen
+		}
+		if sighting.Sample == nil {
+			sighting.Sample = existing.Sample
+		}
+		s.sightings[sighting.EventType] = sighting
+	}
+	return nil
+}
+
+func (s *memoryStore) Sightings(context.Context) ([]Sighting, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var sightings []Sighting
+	for _, sighting := range s.sightings {
+		sightings = append(sightings, sighting)
+	}
+	return sightings, nil
+}
+
+// fixedSchemas registers form.published at version 3
+type fixedSchemas struct{}
+
+func (fixedSchemas) LatestVersion(_ context.Context, eventType string) (int, bool, error) {
+	if eventType == "form.published" {
+		return 3, true, nil
+	}
+	return 0, false, nil
+}
+
+func testConfig() config.CatalogConfig {
+	return config.CatalogConfig{
+		Enabled: true, FlushInterval: time.Minute, StaleAfter: 30 * 24 * time.Hour,
+		SampleMaxBytes: 4096, MaxEventTypes: 100,
+	}
+}
+
+func newTestCatalog(store Store, now *time.Time) *Catalog {
+	c := New(testConfig(), store, fixedSchemas{}, zap.NewNop())
+	c.now = func() time.Time { return *now }
+	c.tracker.now = func() time.Time { return *now }
+	return c
+}
+
+// TestReportJSON pins the JSON of the catalog, which the developer portal
+// reads
+func TestReportJSON(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	c := newTestCatalog(newMemoryStore(), &now)
+	ctx := context.Background()
+
+	if _, err := c.Register(ctx, Registration{
+		Service: "form-service", Produces: []string{"form.published"}, Team: "forms",
+		SchemaRef: "https://schemas.example/form-service",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Register(ctx, Registration{Service: "notifier", Consumes: []string{"form.published"}}); err != nil {
+		t.Fatal(err)
+	}
+	c.tracker.ObserveEvent(&kafka.Message{
+		EventType: "form.published",
+		Data:      json.RawMessage(`{"form_id":"f-1","title":"Intake","questions":[{"id":"q-1","required":true},{"id":"q-2"}],"version":2}`),
+	})
+
+	report, err := c.Report(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "event_types": [
+    {
+      "event_type": "form.published",
+      "producers": [
+        {
+          "service": "form-service",
+          "team": "forms",
+          "schema_ref": "https://schemas.example/form-service",
+          "heartbeat_at": "2026-10-01T12:00:00Z",
+          "stale": false
+        }
+      ],
+      "consumers": [
+        {
+          "service": "notifier",
+          "heartbeat_at": "2026-10-01T12:00:00Z",
+          "stale": false
+        }
+      ],
+      "schema_version": 3,
+      "sample_payload": {
+        "form_id": "string",
+        "questions": [
+          {
+            "id": "string",
+            "required": false
+          }
+        ],
+        "title": "string",
+        "version": 0
+      },
+      "last_seen": "2026-10-01T12:00:00Z"
+    }
+  ],
+  "stale_after": "720h0m0s",
+  "generated_at": "2026-10-01T12:00:00Z"
+}`
+	if string(got) != want {
+		t.Errorf("catalog JSON =\n%s\nwant\n%s", got, want)
+	}
+
+	// Types seen but never registered have empty parties, not nulls
+	c.tracker.ObserveEvent(&kafka.Message{EventType: "form.archived", Data: map[string]interface{}{}})
+	report, _ = c.Report(ctx)
+	archived, _ := json.Marshal(report.EventTypes[0])
+	if want := `{"event_type":"form.archived","producers":[],"consumers":[],"schema_version":null,"sample_payload":{},"last_seen":"2026-10-01T12:00:00Z"}`; string(archived) != want {
+		t.Errorf("unregistered type = %s, want %s", archived, want)
+	}
+}
+
+func TestStaleRegistrations(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	store := newMemoryStore()
+	c := newTestCatalog(store, &now)
+	ctx := context.Background()
+
+	for _, registration := range []Registration{
+		{Service: "form-service", Produces: []string{"form.published"}},
+		{Service: "legacy-exporter", Consumes: []string{"form.exported"}},
+		{Service: "digest", Consumes: []string{"form.digest.due"}},
+	} {
+		if _, err := c.Register(ctx, registration); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 31 days on, form-service heartbeats, digest events still flow and
+	// legacy-exporter is silent
+	now = now.Add(31 * 24 * time.Hour)
+	if _, err := c.Register(ctx, Registration{Service: "form-service", Produces: []string{"form.published"}}); err != nil {
+		t.Fatal(err)
+	}
+	c.tracker.ObserveEvent(&kafka.Message{EventType: "form.digest.due"})
+	c.Flush(ctx)
+
+	report, err := c.Report(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale := make(map[string]bool)
+	for _, eventType := range report.EventTypes {
+		for _, party := range append(eventType.Producers, eventType.Consumers...) {
+			stale[party.Service] = party.Stale
+		}
+	}
+	want := map[string]bool{"form-service": false, "digest": false, "legacy-exporter": true}
+	for service, wantStale := range want {
+		if stale[service] != wantStale {
+			t.Errorf("%s stale = %v, want %v", service, stale[service], wantStale)
+		}
+	}
+	if registered := store.registrations["form-service"]; !registered.RegisteredAt.Before(registered.HeartbeatAt) {
+		t.Errorf("heartbeat moved the registration time: %+v", registered)
+	}
+}
+
+func TestRegisterValidates(t *testing.T) {
+	now := time.Now()
+	c := newTestCatalog(newMemoryStore(), &now)
+
+	for name, registration := range map[string]Registration{
+		"no service":     {Produces: []string{"form.published"}},
+		"no event types": {Service: "form-service"},
+		"empty type":     {Service: "form-service", Produces: []string{""}},
+		"type with comma": {
+			Service: "form-service", Consumes: []string{"form.published,form.archived"},
+		},
+	} {
+		if _, err := c.Register(context.Background(), registration); !errors.Is(err, ErrInvalidRegistration) {
+			t.Errorf("%s: err = %v, want ErrInvalidRegistration", name, err)
+		}
+	}
+
+	registered, err := c.Register(context.Background(), Registration{
+		Service: " form-service ", Produces: []string{"form.published", "form.archived", "form.published"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if registered.Service != "form-service" || len(registered.Produces) != 2 || registered.Produces[0] != "form.archived" ||
+		registered.Consumes == nil || registered.Source != SourceAPI {
+		t.Errorf("registered %+v, want trimmed, sorted and deduplicated", registered)
+	}
+}
+
+func TestTrackerSamplesOncePerFlush(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	store := newMemoryStore()
+	c := newTestCatalog(store, &now)
+	ctx := context.Background()
+
+	c.tracker.ObserveEvent(&kafka.Message{EventType: "form.published", Data: map[string]interface{}{"title": "first"}})
+	c.tracker.ObserveEvent(&kafka.Message{EventType: "form.published", Data: map[string]interface{}{"owner": "second"}})
+	store.failSightings = true
+	c.Flush(ctx)
+	if len(store.sightings) != 0 || len(c.tracker.Pending()) != 1 {
+		t.Fatal("a failed flush dropped the sighting")
+	}
+
+	store.failSightings = false
+	c.Flush(ctx)
+	if sighting := store.sightings["form.published"]; string(sighting.Sample) != `{"title":"string"}` || !sighting.LastSeen.Equal(now) {
+		t.Errorf("flushed %+v, want the sample of the first event", sighting)
+	}
+	if pending := c.tracker.Pending(); len(pending) != 0 {
+		t.Errorf("pending after flush = %+v", pending)
+	}
+
+	// The next event after a flush is sampled again
+	now = now.Add(time.Minute)
+	c.tracker.ObserveEvent(&kafka.Message{EventType: "form.published", Data: map[string]interface{}{"owner": "third"}})
+	c.Flush(ctx)
+	if sighting := store.sightings["form.published"]; string(sighting.Sample) != `{"owner":"string"}` || !sighting.LastSeen.Equal(now) {
+		t.Errorf("flushed %+v, want the sample of the third event", sighting)
+	}
+
+	// Oversized samples are left out, and the tracked types are bounded
+	c.tracker.sampleMaxBytes = 8
+	c.tracker.ObserveEvent(&kafka.Message{EventType: "form.large", Data: map[string]interface{}{"answers": "string"}})
+	c.tracker.maxTypes = c.tracker.count.Load()
+	c.tracker.ObserveEvent(&kafka.Message{EventType: "form.unbounded"})
+	pending := c.tracker.Pending()
+	if len(pending) != 1 || pending[0].EventType != "form.large" || pending[0].Sample != nil {
+		t.Errorf("pending = %+v, want form.large without a sample", pending)
+	}
+}
+
+// TestObserveEventDoesNotAllocate guards the publish and consume paths:
+// once an event type is tracked and sampled, observing its events neither
+// allocates nor locks
+func TestObserveEventDoesNotAllocate(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations are not measured with the race detector")
+	}
+	tracker := NewTracker(4096, 100)
+	message := &kafka.Message{EventType: "form.response.created", Data: json.RawMessage(`{"response_id":"r-1"}`)}
+	tracker.ObserveEvent(message)
+
+	if allocs := testing.AllocsPerRun(1000, func() { tracker.ObserveEvent(message) }); allocs != 0 {
+		t.Errorf("ObserveEvent allocates %v times per event, want 0", allocs)
+	}
+}
+
+func BenchmarkObserveEvent(b *testing.B) {
+	tracker := NewTracker(4096, 100)
+	message := &kafka.Message{EventType: "form.response.created", Data: json.RawMessage(`{"response_id":"r-1"}`)}
+	tracker.ObserveEvent(message)
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			tracker.ObserveEvent(message)
+		}
+	})
+}
+
+// TestPostgresStore registers services and records sightings against a
+// real database when EVENTBUS_TEST_DATABASE_URL is set
+func TestPostgresStore(t *testing.T) {
+	dsn := os.Getenv("EVENTBUS_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("EVENTBUS_TEST_DATABASE_URL not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if _, err := db.ExecContext(ctx, "DROP TABLE IF EXISTS catalog_registrations, catalog_event_types"); err != nil {
+		t.Fatalf("failed to reset tables: %v", err)
+	}
+	store := NewPostgresStore(db)
+	if err := store.EnsureSchema(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	registeredAt := time.Now().UTC().Truncate(time.Microsecond)
+	registration := Registration{
+		Service: "form-service", Produces: []string{"form.archived", "form.published"}, Consumes: []string{},
+		Team: "forms", Source: SourceAPI, RegisteredAt: registeredAt, HeartbeatAt: registeredAt,
+	}
+	if _, err := store.Register(ctx, registration); err != nil {
+		t.Fatal(err)
+	}
+	heartbeat := registration
+	heartbeat.RegisteredAt, heartbeat.HeartbeatAt = registeredAt.Add(time.Hour), registeredAt.Add(time.Hour)
+	stored, err := store.Register(ctx, heartbeat)
+	if err != nil || !stored.RegisteredAt.Equal(registeredAt) {
+		t.Fatalf("heartbeat = %+v, %v, want the first registration time kept", stored, err)
+	}
+
+	registrations, err := store.Registrations(ctx)
+	if err != nil || len(registrations) != 1 {
+		t.Fatalf("registrations = %+v, %v", registrations, err)
+	}
+	if got := registrations[0]; len(got.Produces) != 2 || got.Produces[1] != "form.published" || len(got.Consumes) != 0 ||
+		!got.HeartbeatAt.Equal(heartbeat.HeartbeatAt) {
+		t.Errorf("registration = %+v", got)
+	}
+
+	seen := registeredAt.Add(time.Minute)
+	if err := store.RecordSightings(ctx, []Sighting{{EventType: "form.published", LastSeen: seen, Sample: json.RawMessage(`{"title":"string"}`)}}); err != nil {
+		t.Fatal(err)
+	}
+	// An older sighting without a sample, as flushed by a lagging replica,
+	// moves nothing back
+	if err := store.RecordSightings(ctx, []Sighting{{EventType: "form.published", LastSeen: registeredAt}}); err != nil {
+		t.Fatal(err)
+	}
+	sightings, err := store.Sightings(ctx)
+	if err != nil || len(sightings) != 1 || !sightings[0].LastSeen.Equal(seen) {
+		t.Fatalf("sightings = %+v, %v", sightings, err)
+	}
+	var sample map[string]string
+	if err := json.NewDecoder(bytes.NewReader(sightings[0].Sample)).Decode(&sample); err != nil || sample["title"] != "string" {
+		t.Errorf("sample = %s, %v", sightings[0].Sample, err)
+	}
+}
@@ -0,0 +1,152 @@
+package catalog
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Schema creates the catalog_registrations and catalog_event_types tables
+const Schema = `
+CREATE TABLE IF NOT EXISTS catalog_registrations (
+    service VARCHAR(255) PRIMARY KEY,
+    produces TEXT[] NOT NULL DEFAULT '{}',
+    consumes TEXT[] NOT NULL DEFAULT '{}',
+    schema_ref TEXT NOT NULL DEFAULT '',
+    team VARCHAR(255) NOT NULL DEFAULT '',
+    source VARCHAR(20) NOT NULL,
+    registered_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    heartbeat_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+CREATE TABLE IF NOT EXISTS catalog_event_types (
+    event_type VARCHAR(255) PRIMARY KEY,
+    last_seen TIMESTAMP WITH TIME ZONE NOT NULL,
+    sample JSONB
+);
+`
+
+// PostgresStore keeps the catalog in the catalog_registrations and
+// catalog_event_types tables
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore creates a store writing through db
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// EnsureSchema creates the catalog tables if missing
+func (s *PostgresStore) EnsureSchema(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, Schema); err != nil {
+		return fmt.Errorf("failed to create catalog tables: %w", err)
+	}
+	return nil
+}
+
+// Register creates or refreshes the registration of a service
+func (s *PostgresStore) Register(ctx context.Context, registration Registration) (*Registration, error) {
+	stored := registration
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO catalog_registrations (service, produces, consumes, schema_ref, team, source, registered_at, heartbeat_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (service) DO UPDATE SET
+		    produces = EXCLUDED.produces, consumes = EXCLUDED.consumes, schema_ref = EXCLUDED.schema_ref,
+		    team = EXCLUDED.team, source = EXCLUDED.source, heartbeat_at = EXCLUDED.heartbeat_at
+		RETURNING registered_at`,
+		registration.Service, textArray(registration.Produces), textArray(registration.Consumes),
+		registration.SchemaRef, registration.Team, registration.Source, registration.RegisteredAt, registration.HeartbeatAt,
+	).Scan(&stored.RegisteredAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register %s: %w", registration.Service, err)
+	}
+	return &stored, nil
+}
+
+// Registrations returns the registrations, by service
+func (s *PostgresStore) Registrations(ctx context.Context) ([]Registration, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT service, array_to_string(produces, ','), array_to_string(consumes, ','), schema_ref, team, source,
+		    registered_at, heartbeat_at
+		FROM catalog_registrations ORDER BY service`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog registrations: %w", err)
+	}
+	defer rows.Close()
+
+	registrations := []Registration{}
+	for rows.Next() {
+		var registration Registration
+		var produces, consumes string
+		if err := rows.Scan(&registration.Service, &produces, &consumes, &registration.SchemaRef, &registration.Team,
+			&registration.Source, &registration.RegisteredAt, &registration.HeartbeatAt); err != nil {
+			return nil, fmt.Errorf("failed to read catalog registration: %w", err)
+		}
+		registration.Produces, registration.Consumes = splitTypes(produces), splitTypes(consumes)
+		registrations = append(registrations, registration)
+	}
+	return registrations, rows.Err()
+}
+
+// RecordSightings moves the last seen times of the event types forward
+func (s *PostgresStore) RecordSightings(ctx context.Context, sightings []Sighting) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to record sightings: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, sighting := range sightings {
+		var sample interface{}
+		if sighting.Sample != nil {
+			sample = string(sighting.Sample)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO catalog_event_types (event_type, last_seen, sample) VALUES ($1, $2, $3)
+			ON CONFLICT (event_type) DO UPDATE SET
+			    last_seen = GREATEST(catalog_event_types.last_seen, EXCLUDED.last_seen),
+			    sample = COALESCE(EXCLUDED.sample, catalog_event_types.sample)`,
+			sighting.EventType, sighting.LastSeen, sample); err != nil {
+			return fmt.Errorf("failed to record sighting of %s: %w", sighting.EventType, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Sightings returns the last time each event type was seen, with its sample
+func (s *PostgresStore) Sightings(ctx context.Context) ([]Sighting, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT event_type, last_seen, sample FROM catalog_event_types ORDER BY event_type`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog event types: %w", err)
+	}
+	defer rows.Close()
+
+	var sightings []Sighting
+	for rows.Next() {
+		var sighting Sighting
+		var sample []byte
+		if err := rows.Scan(&sighting.EventType, &sighting.LastSeen, &sample); err != nil {
+			return nil, fmt.Errorf("failed to read catalog event type: %w", err)
+		}
+		if sample != nil {
+			sighting.Sample = json.RawMessage(sample)
+		}
+		sightings = append(sightings, sighting)
+	}
+	return sightings, rows.Err()
+}
+
+// textArray encodes event types as a Postgres text array. Event types hold
+// no commas, quotes nor braces, so they are written as they are.
+func textArray(types []string) string {
+	return "{" + strings.Join(types, ",") + "}"
+}
+
+func splitTypes(joined string) []string {
+	if joined == "" {
+		return []string{}
+	}
+	return strings.Split(joined, ",")
+}
@@ -0,0 +1,170 @@
+package catalog
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+)
+
+// Tracker follows the event types published and consumed. Observing an
+// event of a known type stores its time and, once per flush, takes a
+// sample; it neither locks nor allocates otherwise. Sightings are written
+// to the store off that path, by Catalog.Flush.
+type Tracker struct {
+	sampleMaxBytes int
+	maxTypes       int32
+	now            func() time.Time
+
+	types sync.Map // event type to *tracked
+	count atomic.Int32
+}
+
+// tracked is an event type seen in traffic
+type tracked struct {
+	eventType string
+	// lastSeen and flushed are in Unix nanoseconds
+	lastSeen atomic.Int64
+	flushed  atomic.Int64
+	// sampling is set while a sample is wanted; the observer clearing it
+	// takes the sample
+	sampling atomic.Bool
+	sample   atomic.Pointer[json.RawMessage]
+}
+
+// NewTracker creates a tracker keeping samples of up to sampleMaxBytes,
+// and following up to maxTypes event types
+func NewTracker(sampleMaxBytes, maxTypes int) *Tracker {
+	return &Tracker{sampleMaxBytes: sampleMaxBytes, maxTypes: int32(maxTypes), now: time.Now}
+}
+
+// ObserveEvent records that an event of the type of message was seen. It
+// is called by the Kafka client for every event published and consumed.
+func (t *Tracker) ObserveEvent(message *kafka.Message) {
+	if message.EventType == "" {
+		return
+	}
+	entry := t.entry(message.EventType)
+	if entry == nil {
+		return
+	}
+	entry.lastSeen.Store(t.now().UnixNano())
+	if entry.sampling.Load() && entry.sampling.CompareAndSwap(true, false) {
+		if sample := redactedSample(message.Data, t.sampleMaxBytes); sample != nil {
+			entry.sample.Store(&sample)
+		}
+	}
+}
+
+// entry returns the entry of an event type, created unless the tracker
+// follows maxTypes types already
+func (t *Tracker) entry(eventType string) *tracked {
+	if entry, ok := t.types.Load(eventType); ok {
+		return entry.(*tracked)
+	}
+	if t.count.Load() >= t.maxTypes {
+		return nil
+	}
+	created := &tracked{eventType: eventType}
+	created.sampling.Store(true)
+	entry, loaded := t.types.LoadOrStore(eventType, created)
+	if !loaded {
+		t.count.Add(1)
+	}
+	return entry.(*tracked)
+}
+
+// Pending returns the sightings not flushed yet, by event type
+func (t *Tracker) Pending() []Sighting {
+	var sightings []Sighting
+	t.types.Range(func(_, value interface{}) bool {
+		entry := value.(*tracked)
+		lastSeen := entry.lastSeen.Load()
+		if lastSeen == 0 || lastSeen <= entry.flushed.Load() {
+			return true
+		}
+		sighting := Sighting{EventType: entry.eventType, LastSeen: time.Unix(0, lastSeen).UTC()}
+		if sample := entry.sample.Load(); sample != nil {
+			sighting.Sample = *sample
+		}
+		sightings = append(sightings, sighting)
+		return true
+	})
+	sort.Slice(sightings, func(i, j int) bool { return sightings[i].EventType < sightings[j].EventType })
+	return sightings
+}
+
+// Flushed marks sightings as written to the store. Their samples are
+// dropped and a new one is taken from the next event of their type.
+func (t *Tracker) Flushed(sightings []Sighting) {
+	for _, sighting := range sightings {
+		value, ok := t.types.Load(sighting.EventType)
+		if !ok {
+			continue
+		}
+		entry := value.(*tracked)
+		entry.flushed.Store(sighting.LastSeen.UnixNano())
+		if sighting.Sample != nil {
+			entry.sample.Store(nil)
+		}
+		entry.sampling.Store(true)
+	}
+}
+
+// redactedSample returns the shape of data: its objects and their fields,
+// the first element of its arrays, and its values replaced by a
+// placeholder of their type, so that samples never hold what respondents
+// answered. It returns nil for data that is not JSON or whose sample is
+// larger than maxBytes.
+func redactedSample(data interface{}, maxBytes int) json.RawMessage {
+	var raw []byte
+	switch data := data.(type) {
+	case json.RawMessage:
+		raw = data
+	case []byte:
+		raw = data
+	default:
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return nil
+		}
+		raw = encoded
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil
+	}
+	sample, err := json.Marshal(redact(decoded))
+	if err != nil || len(sample) > maxBytes {
+		return nil
+	}
+	return sample
+}
+
+// redact replaces the values of a decoded JSON document by placeholders
+func redact(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			value[key] = redact(field)
+		}
+		return value
+	case []interface{}:
+		if len(value) == 0 {
+			return value
+		}
+		return []interface{}{redact(value[0])}
+	case string:
+		return "string"
+	case float64:
+		return 0
+	case bool:
+		return false
+	default:
+		return nil
+	}
+}
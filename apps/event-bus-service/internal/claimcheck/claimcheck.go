@@ -0,0 +1,33 @@
+// Package claimcheck stores payloads too large for a Kafka message in object
+// storage. The message carries a Reference to the payload instead, which
+// consumers resolve with Store.Get.
+package claimcheck
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotFound is returned when a referenced payload does not exist
+var ErrNotFound = errors.New("claim check payload not found")
+
+// HeaderName marks a message whose data is a Reference
+const HeaderName = "claim-check"
+
+// Reference points to a payload held in object storage
+type Reference struct {
+	Bucket      string `json:"bucket"`
+	Key         string `json:"key"`
+	Size        int    `json:"size"`
+	ContentType string `json:"content_type"`
+	// SHA256 is the hex digest of the payload, to verify it once fetched
+	SHA256 string `json:"sha256"`
+}
+
+// Store keeps large payloads for the messages referencing them
+type Store interface {
+	// Put stores data under key and returns its reference
+	Put(ctx context.Context, key string, data []byte, contentType string) (*Reference, error)
+	// Get returns the payload of a reference or ErrNotFound
+	Get(ctx context.Context, ref *Reference) ([]byte, error)
+}
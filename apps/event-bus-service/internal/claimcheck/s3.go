@@ -0,0 +1,75 @@
+package claimcheck
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Mir00r/X-Form-Backend/shared/storage"
+)
+
+// S3Config holds the settings of an S3-compatible bucket
+type S3Config struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	ForcePathStyle  bool
+}
+
+// S3Store keeps payloads in any S3-compatible store (AWS S3, MinIO, R2...)
+// through the S3 backend of the shared storage
+type S3Store struct {
+	bucket string
+	s3     *storage.S3
+}
+
+// NewS3Store creates a store for the bucket of cfg
+func NewS3Store(cfg S3Config) (*S3Store, error) {
+	s3, err := storage.NewS3(cfg.Endpoint, cfg.Region, cfg.Bucket, cfg.AccessKeyID, cfg.SecretAccessKey, cfg.ForcePathStyle)
+	if err != nil {
+		return nil, err
+	}
+	return &S3Store{bucket: cfg.Bucket, s3: s3}, nil
+}
+
+// Put uploads data under key
+func (s *S3Store) Put(ctx context.Context, key string, data []byte, contentType string) (*Reference, error) {
+	if err := s.s3.Put(ctx, key, contentType, data); err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	return &Reference{
+		Bucket:      s.bucket,
+		Key:         key,
+		Size:        len(data),
+		ContentType: contentType,
+		SHA256:      hex.EncodeToString(sum[:]),
+	}, nil
+}
+
+// Get downloads the payload of ref and verifies its digest
+func (s *S3Store) Get(ctx context.Context, ref *Reference) ([]byte, error) {
+	body, err := s.s3.Open(ctx, ref.Key)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read claim check payload: %w", err)
+	}
+	if sum := sha256.Sum256(data); ref.SHA256 != "" && hex.EncodeToString(sum[:]) != ref.SHA256 {
+		return nil, fmt.Errorf("claim check payload %s does not match its digest", ref.Key)
+	}
+	return data, nil
+}
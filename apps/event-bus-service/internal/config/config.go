@@ -5,8 +5,14 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
 	"os"
+	"path"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -69,6 +75,53 @@ type ServerConfig struct {
 
 	// CORS configuration for web client support
 	CORS CORSConfig `mapstructure:"cors" yaml:"cors" json:"cors"`
+
+	// gRPC configuration for internal event publishing
+	GRPC GRPCConfig `mapstructure:"grpc" yaml:"grpc" json:"grpc"`
+
+	// Server-Sent Events stream of live events for debugging
+	Stream StreamConfig `mapstructure:"stream" yaml:"stream" json:"stream"`
+
+	// Waiting for Kafka and Debezium Connect at startup
+	Startup StartupConfig `mapstructure:"startup" yaml:"startup" json:"startup"`
+}
+
+// StartupConfig bounds the wait for Kafka and Debezium Connect when the
+// service starts. Their connections are retried with exponential backoff
+// from InitialBackoff up to MaxBackoff; the service exits with code 3 when
+// they are still unavailable after Timeout. Meanwhile /health reports
+// starting and /ready 503.
+type StartupConfig struct {
+	Timeout        time.Duration `mapstructure:"timeout" yaml:"timeout" json:"timeout"`
+	InitialBackoff time.Duration `mapstructure:"initial_backoff" yaml:"initial_backoff" json:"initial_backoff"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff" yaml:"max_backoff" json:"max_backoff"`
+}
+
+// StreamConfig defines GET /events/stream, which tails live events over
+// Server-Sent Events. Streams require an API key of security.api_keys.
+type StreamConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	// Most streams open at once across all clients
+	MaxStreams int `mapstructure:"max_streams" yaml:"max_streams" json:"max_streams"`
+	// Most events per second sent to one stream; the rest are dropped
+	RateLimit float64 `mapstructure:"rate_limit" yaml:"rate_limit" json:"rate_limit"`
+	// Interval of the comments keeping idle streams open through proxies
+	Heartbeat time.Duration `mapstructure:"heartbeat" yaml:"heartbeat" json:"heartbeat"`
+	// Prefix of the ephemeral consumer group of each stream
+	GroupPrefix string `mapstructure:"group_prefix" yaml:"group_prefix" json:"group_prefix"`
+}
+
+// GRPCConfig defines the internal gRPC server configuration
+type GRPCConfig struct {
+	Enabled bool   `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	Host    string `mapstructure:"host" yaml:"host" json:"host"`
+	Port    string `mapstructure:"port" yaml:"port" json:"port"`
+
+	// Largest request message accepted, in bytes
+	MaxRecvMsgSize int `mapstructure:"max_recv_msg_size" yaml:"max_recv_msg_size" json:"max_recv_msg_size"`
+
+	// TLS configuration; setting ClientCAFile enables mutual TLS
+	TLS TLSConfig `mapstructure:"tls" yaml:"tls" json:"tls"`
 }
 
 // TLSConfig defines TLS/SSL configuration
@@ -76,6 +129,9 @@ type TLSConfig struct {
 	Enabled  bool   `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
 	CertFile string `mapstructure:"cert_file" yaml:"cert_file" json:"cert_file"`
 	KeyFile  string `mapstructure:"key_file" yaml:"key_file" json:"key_file"`
+
+	// ClientCAFile, when set, requires clients to present a certificate signed by this CA
+	ClientCAFile string `mapstructure:"client_ca_file" yaml:"client_ca_file" json:"client_ca_file"`
 }
 
 // CORSConfig defines Cross-Origin Resource Sharing configuration
@@ -105,8 +161,41 @@ type KafkaConfig struct {
 	// Admin configuration for topic management
 	Admin KafkaAdminConfig `mapstructure:"admin" yaml:"admin" json:"admin"`
 
+	// Health check of the cluster reported by /health
+	Health KafkaHealthConfig `mapstructure:"health" yaml:"health" json:"health"`
+
+	// Sampling of the messages and log sizes of the topics reported by
+	// /admin/topic-stats
+	Stats KafkaStatsConfig `mapstructure:"stats" yaml:"stats" json:"stats"`
+
+	// Failover to a secondary cluster while the brokers are unhealthy
+	Failover KafkaFailoverConfig `mapstructure:"failover" yaml:"failover" json:"failover"`
+
+	// Topics declares the partitions and policies of the topics
+	Topics KafkaTopicsConfig `mapstructure:"topics" yaml:"topics" json:"topics"`
+
+	// Encryption of the data of the messages of the encrypted topics
+	Encryption KafkaEncryptionConfig `mapstructure:"encryption" yaml:"encryption" json:"encryption"`
+
 	// Schema Registry configuration for Avro/JSON Schema support
 	SchemaRegistry SchemaRegistryConfig `mapstructure:"schema_registry" yaml:"schema_registry" json:"schema_registry"`
+
+	// EventFormat is the format messages are published in: native or cloudevents
+	EventFormat string `mapstructure:"event_format" yaml:"event_format" json:"event_format"`
+
+	// TopicEventFormats overrides EventFormat for individual topics
+	TopicEventFormats map[string]string `mapstructure:"topic_event_formats" yaml:"topic_event_formats" json:"topic_event_formats"`
+}
+
+// EventFormatFor returns the format messages on topic are published in
+func (k KafkaConfig) EventFormatFor(topic string) string {
+	if format, ok := k.TopicEventFormats[topic]; ok && format != "" {
+		return format
+	}
+	if k.EventFormat == "" {
+		return "native"
+	}
+	return k.EventFormat
 }
 
 // KafkaSecurityConfig defines Kafka security settings
@@ -151,6 +240,43 @@ type KafkaProducerConfig struct {
 	FlushBytes      int           `mapstructure:"flush_bytes" yaml:"flush_bytes" json:"flush_bytes"`
 	Idempotent      bool          `mapstructure:"idempotent" yaml:"idempotent" json:"idempotent"`
 	TransactionID   string        `mapstructure:"transaction_id" yaml:"transaction_id" json:"transaction_id"`
+
+	// LargeMessageMode handles messages over MaxMessageBytes: reject, or
+	// claim_check to store the payload in ClaimCheck and publish a reference
+	LargeMessageMode string                `mapstructure:"large_message_mode" yaml:"large_message_mode" json:"large_message_mode"`
+	ClaimCheck       KafkaClaimCheckConfig `mapstructure:"claim_check" yaml:"claim_check" json:"claim_check"`
+
+	// Backpressure bounds the messages waiting on the brokers; publishes
+	// past it fail right away
+	Backpressure KafkaBackpressureConfig `mapstructure:"backpressure" yaml:"backpressure" json:"backpressure"`
+
+	// PublishMode is how the HTTP API publishes events: sync answers once
+	// the brokers acknowledged the event, async once it is queued
+	PublishMode    string `mapstructure:"publish_mode" yaml:"publish_mode" json:"publish_mode"`
+	AsyncQueueSize int    `mapstructure:"async_queue_size" yaml:"async_queue_size" json:"async_queue_size"`
+	AsyncWorkers   int    `mapstructure:"async_workers" yaml:"async_workers" json:"async_workers"`
+}
+
+// KafkaBackpressureConfig bounds the messages being sent to the brokers. A
+// zero limit is no limit.
+type KafkaBackpressureConfig struct {
+	MaxInFlightMessages int   `mapstructure:"max_in_flight_messages" yaml:"max_in_flight_messages" json:"max_in_flight_messages"`
+	MaxInFlightBytes    int64 `mapstructure:"max_in_flight_bytes" yaml:"max_in_flight_bytes" json:"max_in_flight_bytes"`
+	// RetryAfter is the delay suggested to rejected clients, a second
+	// when zero
+	RetryAfter time.Duration `mapstructure:"retry_after" yaml:"retry_after" json:"retry_after"`
+}
+
+// KafkaClaimCheckConfig defines the S3-compatible bucket oversized payloads are stored in
+type KafkaClaimCheckConfig struct {
+	Endpoint        string `mapstructure:"endpoint" yaml:"endpoint" json:"endpoint"`
+	Region          string `mapstructure:"region" yaml:"region" json:"region"`
+	Bucket          string `mapstructure:"bucket" yaml:"bucket" json:"bucket"`
+	AccessKeyID     string `mapstructure:"access_key_id" yaml:"access_key_id" json:"-"`
+	SecretAccessKey string `mapstructure:"secret_access_key" yaml:"secret_access_key" json:"-"`
+	ForcePathStyle  bool   `mapstructure:"force_path_style" yaml:"force_path_style" json:"force_path_style"`
+	// Prefix is prepended to the object keys, e.g. claim-checks/app.form.created/<event id>
+	Prefix string `mapstructure:"prefix" yaml:"prefix" json:"prefix"`
 }
 
 // KafkaConsumerConfig defines Kafka consumer settings
@@ -171,6 +297,15 @@ type KafkaConsumerConfig struct {
 	ReturnErrors       bool          `mapstructure:"return_errors" yaml:"return_errors" json:"return_errors"`
 	OffsetsInitial     int64         `mapstructure:"offsets_initial" yaml:"offsets_initial" json:"offsets_initial"`
 	OffsetsRetention   time.Duration `mapstructure:"offsets_retention" yaml:"offsets_retention" json:"offsets_retention"`
+	// InstanceID is the group.instance.id of static group membership,
+	// unique to each replica and stable across its restarts, such as
+	// event-bus-${POD_NAME}; environment variables are expanded. A member
+	// rejoining within the session timeout gets its partitions back without
+	// a rebalance. Empty joins the groups dynamically.
+	InstanceID string `mapstructure:"instance_id" yaml:"instance_id" json:"instance_id"`
+	// RebalanceStrategy assigns the partitions of the groups: range,
+	// roundrobin, sticky or cooperative-sticky
+	RebalanceStrategy string `mapstructure:"rebalance_strategy" yaml:"rebalance_strategy" json:"rebalance_strategy"`
 }
 
 // KafkaAdminConfig defines Kafka admin client settings
@@ -178,6 +313,194 @@ type KafkaAdminConfig struct {
 	Timeout time.Duration `mapstructure:"timeout" yaml:"timeout" json:"timeout"`
 }
 
+// KafkaHealthConfig defines the health check of the Kafka cluster. Results
+// are reused for CacheTTL, so a broker outage shows within it.
+type KafkaHealthConfig struct {
+	// Timeout bounds each step of a check
+	Timeout  time.Duration `mapstructure:"timeout" yaml:"timeout" json:"timeout"`
+	CacheTTL time.Duration `mapstructure:"cache_ttl" yaml:"cache_ttl" json:"cache_ttl"`
+	// MinHealthyBrokers is the number of brokers that must be reachable
+	MinHealthyBrokers int `mapstructure:"min_healthy_brokers" yaml:"min_healthy_brokers" json:"min_healthy_brokers"`
+	// CriticalTopics must have a leader for each of their partitions
+	CriticalTopics []string `mapstructure:"critical_topics" yaml:"critical_topics" json:"critical_topics"`
+	// CanaryEnabled produces a message to CanaryTopic on each check and
+	// consumes it back
+	CanaryEnabled bool   `mapstructure:"canary_enabled" yaml:"canary_enabled" json:"canary_enabled"`
+	CanaryTopic   string `mapstructure:"canary_topic" yaml:"canary_topic" json:"canary_topic"`
+}
+
+// KafkaStatsConfig defines the topic stats. The sizes of the messages
+// published and consumed are sampled into a reservoir of ReservoirSize per
+// topic and direction, and their rate is measured over RateWindow. The log
+// sizes of the topics are read from the brokers every LogSizeInterval.
+type KafkaStatsConfig struct {
+	Enabled       bool          `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	ReservoirSize int           `mapstructure:"reservoir_size" yaml:"reservoir_size" json:"reservoir_size"`
+	RateWindow    time.Duration `mapstructure:"rate_window" yaml:"rate_window" json:"rate_window"`
+	// LogSizeInterval is how often log sizes are read; 0 never reads them
+	LogSizeInterval time.Duration `mapstructure:"log_size_interval" yaml:"log_size_interval" json:"log_size_interval"`
+	// TopTopics is the number of busiest topics labelled by name in the
+	// metrics; the others are labelled other
+	TopTopics int `mapstructure:"top_topics" yaml:"top_topics" json:"top_topics"`
+	// MaxTopics bounds the topics tracked; messages of further topics are
+	// counted under other
+	MaxTopics int `mapstructure:"max_topics" yaml:"max_topics" json:"max_topics"`
+}
+
+// KafkaFailoverConfig defines the failover of the producer from the
+// brokers, the primary cluster, to a secondary cluster. The primary is
+// checked every CheckInterval with the health check; once it has been
+// unhealthy for GracePeriod the producer moves to the secondary, and moves
+// back once the primary has been healthy for FailbackAfter. Consumers never
+// move by themselves, as offsets differ between clusters.
+type KafkaFailoverConfig struct {
+	Enabled          bool     `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	SecondaryBrokers []string `mapstructure:"secondary_brokers" yaml:"secondary_brokers" json:"secondary_brokers"`
+
+	CheckInterval time.Duration `mapstructure:"check_interval" yaml:"check_interval" json:"check_interval"`
+	GracePeriod   time.Duration `mapstructure:"grace_period" yaml:"grace_period" json:"grace_period"`
+	FailbackAfter time.Duration `mapstructure:"failback_after" yaml:"failback_after" json:"failback_after"`
+
+	// SwitchoverPolicy is what publishes do while the primary is unhealthy
+	// within the grace period: buffer waits for the switchover, bounded by
+	// the backpressure limits and SwitchoverTimeout, and fail_fast refuses
+	// them right away. Either way they fail with a backpressure error.
+	SwitchoverPolicy string `mapstructure:"switchover_policy" yaml:"switchover_policy" json:"switchover_policy"`
+	// SwitchoverTimeout is the longest a buffered publish waits, the grace
+	// period and a check interval when zero
+	SwitchoverTimeout time.Duration `mapstructure:"switchover_timeout" yaml:"switchover_timeout" json:"switchover_timeout"`
+
+	// EventTopic receives an event on each failover and failback; none is
+	// published when empty
+	EventTopic string `mapstructure:"event_topic" yaml:"event_topic" json:"event_topic"`
+}
+
+// KafkaTopicsConfig declares the topics provisioned by the service
+type KafkaTopicsConfig struct {
+	// AutoProvision creates unknown topics before their first message is published
+	AutoProvision bool `mapstructure:"auto_provision" yaml:"auto_provision" json:"auto_provision"`
+	// Defaults apply to the settings a spec leaves out and to topics no spec matches
+	Defaults TopicSpec `mapstructure:"defaults" yaml:"defaults" json:"defaults"`
+	// Specs are matched by name first, then by pattern in order
+	Specs []TopicSpec `mapstructure:"specs" yaml:"specs" json:"specs"`
+}
+
+// TopicSpec defines the partitions and policies of a topic. Specs with a
+// name are reconciled at startup; specs with a pattern apply to the topics
+// provisioned on first publish.
+type TopicSpec struct {
+	Name string `mapstructure:"name" yaml:"name" json:"name,omitempty"`
+	// Pattern is a path.Match pattern, e.g. app.form.*
+	Pattern           string `mapstructure:"pattern" yaml:"pattern" json:"pattern,omitempty"`
+	Partitions        int32  `mapstructure:"partitions" yaml:"partitions" json:"partitions"`
+	ReplicationFactor int16  `mapstructure:"replication_factor" yaml:"replication_factor" json:"replication_factor"`
+	// RetentionMs is the topic retention.ms; 0 keeps the broker default and -1 retains forever
+	RetentionMs   int64  `mapstructure:"retention_ms" yaml:"retention_ms" json:"retention_ms"`
+	CleanupPolicy string `mapstructure:"cleanup_policy" yaml:"cleanup_policy" json:"cleanup_policy"` // delete, compact or compact,delete
+	// Encrypted publishes the data of the messages encrypted with the keys
+	// of kafka.encryption
+	Encrypted bool `mapstructure:"encrypted" yaml:"encrypted" json:"encrypted,omitempty"`
+}
+
+// SpecFor returns the spec of topic, with the defaults filled in
+func (t KafkaTopicsConfig) SpecFor(topic string) TopicSpec {
+	spec, found := TopicSpec{}, false
+	for _, s := range t.Specs {
+		if s.Name == topic {
+			spec, found = s, true
+			break
+		}
+	}
+	if !found {
+		for _, s := range t.Specs {
+			if s.Pattern == "" {
+				continue
+			}
+			if ok, _ := path.Match(s.Pattern, topic); ok {
+				spec = s
+				break
+			}
+		}
+	}
+
+	spec.Name, spec.Pattern = topic, ""
+	if spec.Partitions == 0 {
+		spec.Partitions = t.Defaults.Partitions
+	}
+	if spec.ReplicationFactor == 0 {
+		spec.ReplicationFactor = t.Defaults.ReplicationFactor
+	}
+	if spec.RetentionMs == 0 {
+		spec.RetentionMs = t.Defaults.RetentionMs
+	}
+	if spec.CleanupPolicy == "" {
+		spec.CleanupPolicy = t.Defaults.CleanupPolicy
+	}
+	spec.Encrypted = spec.Encrypted || t.Defaults.Encrypted
+	return spec
+}
+
+// Encrypted reports whether any topic is encrypted
+func (t KafkaTopicsConfig) Encrypted() bool {
+	if t.Defaults.Encrypted {
+		return true
+	}
+	for _, spec := range t.Specs {
+		if spec.Encrypted {
+			return true
+		}
+	}
+	return false
+}
+
+// KafkaEncryptionConfig defines the envelope encryption of the data of the
+// messages of the topics whose spec is encrypted. The data of each message
+// is encrypted with a data key, itself encrypted by the KMS; the processors
+// of the service decrypt it, other consumers only see ciphertext.
+type KafkaEncryptionConfig struct {
+	// KMS encrypting the data keys: local or aws
+	KMS string `mapstructure:"kms" yaml:"kms" json:"kms"`
+	// DataKeyTTL is how long a data key encrypts messages before the next
+	// one is generated
+	DataKeyTTL time.Duration `mapstructure:"data_key_ttl" yaml:"data_key_ttl" json:"data_key_ttl"`
+
+	Local LocalKMSConfig `mapstructure:"local" yaml:"local" json:"local"`
+	AWS   AWSKMSConfig   `mapstructure:"aws" yaml:"aws" json:"aws"`
+}
+
+// Configured reports whether the keys of the KMS are set. Messages are
+// decrypted as long as they are, even once no topic is encrypted any more.
+func (e KafkaEncryptionConfig) Configured() bool {
+	if e.KMS == "aws" {
+		return e.AWS.KeyID != ""
+	}
+	return len(e.Local.Keys) > 0
+}
+
+// LocalKMSConfig defines the keys of the local KMS, for development. Each key
+// is 32 bytes in base64, read from the secret <secret_prefix>/<key ID>.
+type LocalKMSConfig struct {
+	// ActiveKey encrypts the new data keys
+	ActiveKey string `mapstructure:"active_key" yaml:"active_key" json:"active_key"`
+	// Keys are the IDs of the keys decrypting data keys, the active one
+	// included; keep a rotated key until its messages expired
+	Keys         []string `mapstructure:"keys" yaml:"keys" json:"keys"`
+	SecretPrefix string   `mapstructure:"secret_prefix" yaml:"secret_prefix" json:"secret_prefix"`
+	// Secrets resolves the keys like the Debezium connector secrets
+	Secrets DebeziumSecretsConfig `mapstructure:"secrets" yaml:"secrets" json:"secrets"`
+}
+
+// AWSKMSConfig defines the AWS KMS key encrypting the data keys
+type AWSKMSConfig struct {
+	Region string `mapstructure:"region" yaml:"region" json:"region"`
+	// KeyID is the ID, ARN or alias of the key
+	KeyID           string `mapstructure:"key_id" yaml:"key_id" json:"key_id"`
+	Endpoint        string `mapstructure:"endpoint" yaml:"endpoint" json:"endpoint"`
+	AccessKeyID     string `mapstructure:"access_key_id" yaml:"access_key_id" json:"-"`
+	SecretAccessKey string `mapstructure:"secret_access_key" yaml:"secret_access_key" json:"-"`
+	SessionToken    string `mapstructure:"session_token" yaml:"session_token" json:"-"`
+}
+
 // SchemaRegistryConfig defines Confluent Schema Registry configuration
 type SchemaRegistryConfig struct {
 	Enabled bool     `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
@@ -186,6 +509,20 @@ type SchemaRegistryConfig struct {
 		Username string `mapstructure:"username" yaml:"username" json:"username"`
 		Password string `mapstructure:"password" yaml:"password" json:"password"`
 	} `mapstructure:"auth" yaml:"auth" json:"auth"`
+	// Timeout bounds each call to the registry
+	Timeout       time.Duration             `mapstructure:"timeout" yaml:"timeout" json:"timeout"`
+	Compatibility SchemaCompatibilityConfig `mapstructure:"compatibility" yaml:"compatibility" json:"compatibility"`
+}
+
+// SchemaCompatibilityConfig checks the data of published events against the
+// latest schema registered for their type
+type SchemaCompatibilityConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	// Mode is "auto_register", registering compatible changes as a new
+	// version and rejecting the others, or "strict", rejecting any change
+	Mode string `mapstructure:"mode" yaml:"mode" json:"mode"`
+	// CacheTTL is how long the latest version of an event type is cached
+	CacheTTL time.Duration `mapstructure:"cache_ttl" yaml:"cache_ttl" json:"cache_ttl"`
 }
 
 // DebeziumConfig defines Debezium Change Data Capture configuration
@@ -200,6 +537,9 @@ type DebeziumConfig struct {
 
 	// Monitoring and health configuration
 	Monitoring DebeziumMonitoringConfig `mapstructure:"monitoring" yaml:"monitoring" json:"monitoring"`
+
+	// Secrets resolves the ${secret:key} references of connector configs
+	Secrets DebeziumSecretsConfig `mapstructure:"secrets" yaml:"secrets" json:"secrets"`
 }
 
 // DebeziumConnectConfig defines Kafka Connect configuration for Debezium
@@ -227,6 +567,17 @@ type TopicConfig struct {
 	TransactionName string `mapstructure:"transaction_name" yaml:"transaction_name" json:"transaction_name"`
 }
 
+// DebeziumSecretsConfig defines where the ${secret:key} references of
+// connector configs are resolved, just before the configs are sent to Kafka
+// Connect. The environment provider reads key postgres/password from
+// Prefix+POSTGRES_PASSWORD; the file provider reads Path/postgres/password,
+// as mounted by Kubernetes secrets.
+type DebeziumSecretsConfig struct {
+	Provider string `mapstructure:"provider" yaml:"provider" json:"provider"` // environment or file
+	Prefix   string `mapstructure:"prefix" yaml:"prefix" json:"prefix"`
+	Path     string `mapstructure:"path" yaml:"path" json:"path"`
+}
+
 // DebeziumMonitoringConfig defines monitoring configuration for Debezium
 type DebeziumMonitoringConfig struct {
 	Enabled        bool          `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
@@ -289,8 +640,42 @@ type SecurityConfig struct {
 	// API key configuration for service-to-service communication
 	APIKeys APIKeysConfig `mapstructure:"api_keys" yaml:"api_keys" json:"api_keys"`
 
+	// AdminKeys authenticate the operators of the consumer group admin
+	// endpoints, by name; the name is the actor of their audit events
+	AdminKeys APIKeysConfig `mapstructure:"admin_keys" yaml:"admin_keys" json:"admin_keys"`
+
 	// Event signing configuration for message integrity
 	EventSigning EventSigningConfig `mapstructure:"event_signing" yaml:"event_signing" json:"event_signing"`
+
+	// PublishACL restricts the topics callers may publish to
+	PublishACL PublishACLConfig `mapstructure:"publish_acl" yaml:"publish_acl" json:"publish_acl"`
+}
+
+// PublishACLConfig restricts the topics the callers of the publish APIs may
+// publish to. Callers are known by principals of the form kind:name:
+// san:<name> for each DNS and URI SAN of an mTLS client certificate,
+// api_key:<name> for the keys of security.api_keys and svc:<name> for the
+// svc claim of a JWT signed with security.jwt.secret.
+type PublishACLConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	// DefaultAction applies to the topics no rule of the caller covers:
+	// allow or deny
+	DefaultAction string `mapstructure:"default_action" yaml:"default_action" json:"default_action"`
+	// ProtectedTopics are patterns of topics denied to callers no rule
+	// explicitly allows, whatever the default action
+	ProtectedTopics []string `mapstructure:"protected_topics" yaml:"protected_topics" json:"protected_topics"`
+	// Rules are evaluated together: a deny of any rule matching the caller
+	// wins over the allows
+	Rules []PublishACLRule `mapstructure:"rules" yaml:"rules" json:"rules"`
+}
+
+// PublishACLRule gives a principal the topics it may and may not publish
+// to. Topic patterns match with *, which matches any run of characters.
+type PublishACLRule struct {
+	// Principal is kind:name, where a name of * matches any of the kind
+	Principal string   `mapstructure:"principal" yaml:"principal" json:"principal" example:"svc:response-service"`
+	Allow     []string `mapstructure:"allow" yaml:"allow" json:"allow,omitempty" example:"form.response.*"`
+	Deny      []string `mapstructure:"deny" yaml:"deny" json:"deny,omitempty"`
 }
 
 // JWTConfig defines JWT authentication configuration
@@ -327,6 +712,21 @@ type ObservabilityConfig struct {
 
 	// Health check configuration
 	Health HealthConfig `mapstructure:"health" yaml:"health" json:"health"`
+
+	// Error reporting of the messages that fail to be processed
+	Errors ErrorReportingConfig `mapstructure:"errors" yaml:"errors" json:"errors"`
+}
+
+// ErrorReportingConfig selects the error tracker the messages consumers and
+// processors fail to handle are reported to: noop, sentry, which needs DSN,
+// or otel, which records them on the spans of their traces. Each error is
+// reported Burst times per Interval, plus SampleRate of the rest.
+type ErrorReportingConfig struct {
+	Provider   string        `mapstructure:"provider" yaml:"provider" json:"provider"`
+	DSN        string        `mapstructure:"dsn" yaml:"dsn" json:"-"`
+	Burst      int           `mapstructure:"burst" yaml:"burst" json:"burst"`
+	Interval   time.Duration `mapstructure:"interval" yaml:"interval" json:"interval"`
+	SampleRate float64       `mapstructure:"sample_rate" yaml:"sample_rate" json:"sample_rate"`
 }
 
 // MetricsConfig defines Prometheus metrics configuration
@@ -379,6 +779,54 @@ type EventProcessingConfig struct {
 
 	// Event ordering configuration
 	Ordering OrderingConfig `mapstructure:"ordering" yaml:"ordering" json:"ordering"`
+
+	// Response projection written from form.response.created events
+	ResponseProjection ResponseProjectionConfig `mapstructure:"response_projection" yaml:"response_projection" json:"response_projection"`
+
+	// Daily funnels of forms counted from funnel beacons and submissions
+	FunnelProjection FunnelProjectionConfig `mapstructure:"funnel_projection" yaml:"funnel_projection" json:"funnel_projection"`
+
+	// Event store sink searched by POST /events/filter
+	EventStore EventStoreSinkConfig `mapstructure:"event_store" yaml:"event_store" json:"event_store"`
+
+	// Delayed delivery of the events published with a deliver_at
+	ScheduledEvents ScheduledEventsConfig `mapstructure:"scheduled_events" yaml:"scheduled_events" json:"scheduled_events"`
+
+	// Catalog of the event types and of the services producing and
+	// consuming them, served by /catalog
+	Catalog CatalogConfig `mapstructure:"catalog" yaml:"catalog" json:"catalog"`
+
+	// Email notifications of form owners and respondents
+	Notifications NotificationsConfig `mapstructure:"notifications" yaml:"notifications" json:"notifications"`
+
+	// Data subject erasure and export requests published by the gateway
+	Privacy PrivacyConfig `mapstructure:"privacy" yaml:"privacy" json:"privacy"`
+
+	// Audit log appended from the audit events of the services
+	Audit AuditConfig `mapstructure:"audit" yaml:"audit" json:"audit"`
+
+	// Throttling of forms whose submissions surge
+	Anomaly AnomalyConfig `mapstructure:"anomaly" yaml:"anomaly" json:"anomaly"`
+
+	// Live response counts pushed to the dashboards of form owners
+	LiveStats LiveStatsConfig `mapstructure:"live_stats" yaml:"live_stats" json:"live_stats"`
+
+	// Invalidations of the form caches of the gateways
+	CacheInvalidation CacheInvalidationConfig `mapstructure:"cache_invalidation" yaml:"cache_invalidation" json:"cache_invalidation"`
+
+	// Event processors run by the processor manager, by name
+	Processors map[string]ProcessorConfig `mapstructure:"processors" yaml:"processors" json:"processors"`
+}
+
+// ProcessorConfig defines an event processor. Type selects the factory the
+// processor is created with, and defaults to the name of the processor;
+// Topics overrides the topics of the type and Settings are specific to it.
+// Disabled processors can still be started at runtime.
+type ProcessorConfig struct {
+	Type     string                 `mapstructure:"type" yaml:"type" json:"type"`
+	Enabled  bool                   `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	Topics   []string               `mapstructure:"topics" yaml:"topics" json:"topics"`
+	Settings map[string]interface{} `mapstructure:"settings" yaml:"settings" json:"settings"`
 }
 
 // DeadLetterConfig defines dead letter queue configuration
@@ -403,6 +851,220 @@ type OrderingConfig struct {
 	PartitionKey string        `mapstructure:"partition_key" yaml:"partition_key" json:"partition_key"`
 }
 
+// ResponseProjectionConfig defines the projection of submitted responses into
+// the response_events table of the event store database
+type ResponseProjectionConfig struct {
+	Enabled       bool          `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	Topics        []string      `mapstructure:"topics" yaml:"topics" json:"topics"`
+	GroupID       string        `mapstructure:"group_id" yaml:"group_id" json:"group_id"`
+	BatchSize     int           `mapstructure:"batch_size" yaml:"batch_size" json:"batch_size"`
+	FlushInterval time.Duration `mapstructure:"flush_interval" yaml:"flush_interval" json:"flush_interval"`
+}
+
+// FunnelProjectionConfig defines the projection of the funnel stages of
+// sessions and of submissions into the form_funnel_daily table of the event
+// store database. The stages of sessions are remembered for
+// SessionRetention to skip redeliveries.
+type FunnelProjectionConfig struct {
+	Enabled          bool          `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	Topics           []string      `mapstructure:"topics" yaml:"topics" json:"topics"`
+	GroupID          string        `mapstructure:"group_id" yaml:"group_id" json:"group_id"`
+	BatchSize        int           `mapstructure:"batch_size" yaml:"batch_size" json:"batch_size"`
+	FlushInterval    time.Duration `mapstructure:"flush_interval" yaml:"flush_interval" json:"flush_interval"`
+	SessionRetention time.Duration `mapstructure:"session_retention" yaml:"session_retention" json:"session_retention"`
+}
+
+// NotificationsConfig defines the notification worker emailing form owners
+// when responses arrive and respondents a copy of their answers
+type NotificationsConfig struct {
+	Enabled       bool          `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	Topics        []string      `mapstructure:"topics" yaml:"topics" json:"topics"`
+	GroupID       string        `mapstructure:"group_id" yaml:"group_id" json:"group_id"`
+	BatchSize     int           `mapstructure:"batch_size" yaml:"batch_size" json:"batch_size"`
+	FlushInterval time.Duration `mapstructure:"flush_interval" yaml:"flush_interval" json:"flush_interval"`
+	// Sender is smtp, or console to log emails in development
+	Sender string     `mapstructure:"sender" yaml:"sender" json:"sender"`
+	From   string     `mapstructure:"from" yaml:"from" json:"from"`
+	SMTP   SMTPConfig `mapstructure:"smtp" yaml:"smtp" json:"smtp"`
+	// FormServiceURL serves the notification settings of forms
+	FormServiceURL   string        `mapstructure:"form_service_url" yaml:"form_service_url" json:"form_service_url"`
+	SettingsCacheTTL time.Duration `mapstructure:"settings_cache_ttl" yaml:"settings_cache_ttl" json:"settings_cache_ttl"`
+	// AppURL is the base of the links in emails
+	AppURL string `mapstructure:"app_url" yaml:"app_url" json:"app_url"`
+	// RateLimit is the most emails sent per form in RateWindow. Owner
+	// notifications over the limit are sent as a digest.
+	RateLimit     int           `mapstructure:"rate_limit" yaml:"rate_limit" json:"rate_limit"`
+	RateWindow    time.Duration `mapstructure:"rate_window" yaml:"rate_window" json:"rate_window"`
+	RetryAttempts int           `mapstructure:"retry_attempts" yaml:"retry_attempts" json:"retry_attempts"`
+	RetryBackoff  time.Duration `mapstructure:"retry_backoff" yaml:"retry_backoff" json:"retry_backoff"`
+	// DeadLetterTopic receives the events whose emails or chat messages
+	// could not be sent
+	DeadLetterTopic string `mapstructure:"dead_letter_topic" yaml:"dead_letter_topic" json:"dead_letter_topic"`
+	// ChannelTimeout bounds each post to the Slack and Teams webhooks of
+	// forms
+	ChannelTimeout time.Duration `mapstructure:"channel_timeout" yaml:"channel_timeout" json:"channel_timeout"`
+}
+
+// PrivacyConfig defines the privacy worker carrying out the erasure and export
+// requests of the gateway. The worker anonymizes or reads the response
+// projection itself and calls the internal APIs of the other services, then
+// publishes their completion events and relays those to the gateway.
+type PrivacyConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	// RequestTopics carry the requests, CompletionTopics the completion of
+	// each participant; completions are relayed in their own group so an
+	// unreachable gateway does not hold the requests up
+	RequestTopics    []string      `mapstructure:"request_topics" yaml:"request_topics" json:"request_topics"`
+	CompletionTopics []string      `mapstructure:"completion_topics" yaml:"completion_topics" json:"completion_topics"`
+	GroupID          string        `mapstructure:"group_id" yaml:"group_id" json:"group_id"`
+	BatchSize        int           `mapstructure:"batch_size" yaml:"batch_size" json:"batch_size"`
+	FlushInterval    time.Duration `mapstructure:"flush_interval" yaml:"flush_interval" json:"flush_interval"`
+	// FormServiceURL and CollaborationServiceURL serve the internal privacy
+	// endpoints of the services, called with ServiceToken
+	FormServiceURL          string `mapstructure:"form_service_url" yaml:"form_service_url" json:"form_service_url"`
+	CollaborationServiceURL string `mapstructure:"collaboration_service_url" yaml:"collaboration_service_url" json:"collaboration_service_url"`
+	ServiceToken            string `mapstructure:"service_token" yaml:"service_token" json:"-"`
+	// GatewayURL receives the completions, authenticated by CallbackToken
+	GatewayURL    string        `mapstructure:"gateway_url" yaml:"gateway_url" json:"gateway_url"`
+	CallbackToken string        `mapstructure:"callback_token" yaml:"callback_token" json:"-"`
+	Timeout       time.Duration `mapstructure:"timeout" yaml:"timeout" json:"timeout"`
+	RetryAttempts int           `mapstructure:"retry_attempts" yaml:"retry_attempts" json:"retry_attempts"`
+	RetryBackoff  time.Duration `mapstructure:"retry_backoff" yaml:"retry_backoff" json:"retry_backoff"`
+}
+
+// AuditConfig defines the sink appending the audit events of the services to
+// the hash-chained audit_log table of the event store database
+type AuditConfig struct {
+	Enabled       bool          `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	Topics        []string      `mapstructure:"topics" yaml:"topics" json:"topics"`
+	GroupID       string        `mapstructure:"group_id" yaml:"group_id" json:"group_id"`
+	BatchSize     int           `mapstructure:"batch_size" yaml:"batch_size" json:"batch_size"`
+	FlushInterval time.Duration `mapstructure:"flush_interval" yaml:"flush_interval" json:"flush_interval"`
+}
+
+// AnomalyConfig defines the anomaly detector throttling forms whose
+// submission rate surges past their baseline. Submissions are counted per
+// form and minute in Redis; the rate over Window is compared against an
+// exponentially weighted moving average of the minutes of BaselinePeriod.
+// The thresholds of each form are read from the form service, which
+// records the throttling.
+type AnomalyConfig struct {
+	Enabled       bool          `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	Topics        []string      `mapstructure:"topics" yaml:"topics" json:"topics"`
+	GroupID       string        `mapstructure:"group_id" yaml:"group_id" json:"group_id"`
+	BatchSize     int           `mapstructure:"batch_size" yaml:"batch_size" json:"batch_size"`
+	FlushInterval time.Duration `mapstructure:"flush_interval" yaml:"flush_interval" json:"flush_interval"`
+	// Window is the period the submission rate is averaged over
+	Window time.Duration `mapstructure:"window" yaml:"window" json:"window"`
+	// BaselinePeriod is the span of the moving average of the rate
+	BaselinePeriod time.Duration `mapstructure:"baseline_period" yaml:"baseline_period" json:"baseline_period"`
+	// EvaluationInterval is how often throttled forms are re-evaluated,
+	// to be released once their submissions normalize
+	EvaluationInterval time.Duration `mapstructure:"evaluation_interval" yaml:"evaluation_interval" json:"evaluation_interval"`
+	// KeyPrefix namespaces the Redis keys of the detector
+	KeyPrefix string `mapstructure:"key_prefix" yaml:"key_prefix" json:"key_prefix"`
+	// FormServiceURL serves and records the throttling of forms, whose
+	// thresholds are cached for SettingsCacheTTL
+	FormServiceURL   string        `mapstructure:"form_service_url" yaml:"form_service_url" json:"form_service_url"`
+	SettingsCacheTTL time.Duration `mapstructure:"settings_cache_ttl" yaml:"settings_cache_ttl" json:"settings_cache_ttl"`
+}
+
+// LiveStatsConfig defines the publisher of the submissions of forms to the
+// Redis pub/sub channels the collaboration service relays to the
+// dashboards of their owners
+type LiveStatsConfig struct {
+	Enabled       bool          `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	Topics        []string      `mapstructure:"topics" yaml:"topics" json:"topics"`
+	GroupID       string        `mapstructure:"group_id" yaml:"group_id" json:"group_id"`
+	BatchSize     int           `mapstructure:"batch_size" yaml:"batch_size" json:"batch_size"`
+	FlushInterval time.Duration `mapstructure:"flush_interval" yaml:"flush_interval" json:"flush_interval"`
+}
+
+// CacheInvalidationConfig defines the bridge of the changes of forms to the
+// Redis pub/sub channel the gateways purge their caches from
+type CacheInvalidationConfig struct {
+	Enabled bool     `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	Topics  []string `mapstructure:"topics" yaml:"topics" json:"topics"`
+	GroupID string   `mapstructure:"group_id" yaml:"group_id" json:"group_id"`
+	Channel string   `mapstructure:"channel" yaml:"channel" json:"channel"`
+	// BatchSize and FlushInterval bound the events coalesced per form
+	BatchSize     int           `mapstructure:"batch_size" yaml:"batch_size" json:"batch_size"`
+	FlushInterval time.Duration `mapstructure:"flush_interval" yaml:"flush_interval" json:"flush_interval"`
+}
+
+// SMTPConfig defines the SMTP server notification emails are sent through
+type SMTPConfig struct {
+	Host     string        `mapstructure:"host" yaml:"host" json:"host"`
+	Port     int           `mapstructure:"port" yaml:"port" json:"port"`
+	Username string        `mapstructure:"username" yaml:"username" json:"username"`
+	Password string        `mapstructure:"password" yaml:"password" json:"-"`
+	Timeout  time.Duration `mapstructure:"timeout" yaml:"timeout" json:"timeout"`
+}
+
+// EventStoreSinkConfig defines the sink storing consumed events in the
+// event_store table of the event store database
+type EventStoreSinkConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	// Topics are the topics stored; all topics when empty
+	Topics        []string      `mapstructure:"topics" yaml:"topics" json:"topics"`
+	GroupID       string        `mapstructure:"group_id" yaml:"group_id" json:"group_id"`
+	BatchSize     int           `mapstructure:"batch_size" yaml:"batch_size" json:"batch_size"`
+	FlushInterval time.Duration `mapstructure:"flush_interval" yaml:"flush_interval" json:"flush_interval"`
+	// Retention is how long events are kept, purged every PurgeInterval
+	Retention     time.Duration `mapstructure:"retention" yaml:"retention" json:"retention"`
+	PurgeInterval time.Duration `mapstructure:"purge_interval" yaml:"purge_interval" json:"purge_interval"`
+}
+
+// ScheduledEventsConfig defines the delayed delivery of events. Events
+// published with a deliver_at are kept in the scheduled_events table of the
+// event store database; the replica elected leader polls them every
+// PollInterval and publishes those due, up to BatchSize per poll.
+type ScheduledEventsConfig struct {
+	Enabled      bool          `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	PollInterval time.Duration `mapstructure:"poll_interval" yaml:"poll_interval" json:"poll_interval"`
+	BatchSize    int           `mapstructure:"batch_size" yaml:"batch_size" json:"batch_size"`
+	// ClaimTimeout is how long the leader has to publish the events it
+	// claimed. Events whose claim lapses, as when the leader dies mid-poll,
+	// are delivered by the next leader; it must exceed the producer timeout.
+	ClaimTimeout time.Duration `mapstructure:"claim_timeout" yaml:"claim_timeout" json:"claim_timeout"`
+	// RetryBackoff is the delay before an event that failed to publish is
+	// tried again
+	RetryBackoff time.Duration `mapstructure:"retry_backoff" yaml:"retry_backoff" json:"retry_backoff"`
+	// MaxDelay is how far in the future events may be scheduled
+	MaxDelay time.Duration `mapstructure:"max_delay" yaml:"max_delay" json:"max_delay"`
+}
+
+// CatalogConfig defines the event type catalog. Services register the
+// event types they produce and consume through POST /catalog/register, or
+// are declared in Registrations. The last time each event type was seen
+// published or consumed, with a sample payload, is kept in the event store
+// database and written every FlushInterval. Registrations without a
+// heartbeat nor traffic of their event types for StaleAfter are flagged
+// stale.
+type CatalogConfig struct {
+	Enabled       bool          `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	FlushInterval time.Duration `mapstructure:"flush_interval" yaml:"flush_interval" json:"flush_interval"`
+	StaleAfter    time.Duration `mapstructure:"stale_after" yaml:"stale_after" json:"stale_after"`
+	// SampleMaxBytes bounds the sample payloads kept; larger ones are not
+	// sampled
+	SampleMaxBytes int `mapstructure:"sample_max_bytes" yaml:"sample_max_bytes" json:"sample_max_bytes"`
+	// MaxEventTypes bounds the event types seen in traffic that are
+	// tracked, so that publishers can't grow the catalog without bound
+	MaxEventTypes int                   `mapstructure:"max_event_types" yaml:"max_event_types" json:"max_event_types"`
+	Registrations []CatalogRegistration `mapstructure:"registrations" yaml:"registrations" json:"registrations"`
+}
+
+// CatalogRegistration declares the event types a service produces and
+// consumes
+type CatalogRegistration struct {
+	Service  string   `mapstructure:"service" yaml:"service" json:"service"`
+	Produces []string `mapstructure:"produces" yaml:"produces" json:"produces"`
+	Consumes []string `mapstructure:"consumes" yaml:"consumes" json:"consumes"`
+	// SchemaRef locates the schemas of the events the service produces
+	SchemaRef string `mapstructure:"schema_ref" yaml:"schema_ref" json:"schema_ref"`
+	Team      string `mapstructure:"team" yaml:"team" json:"team"`
+}
+
 // ServicesConfig defines microservice integration configuration
 type ServicesConfig struct {
 	AuthService          ServiceConfig `mapstructure:"auth_service" yaml:"auth_service" json:"auth_service"`
@@ -447,9 +1109,9 @@ type RateLimitingConfig struct {
 // 2. Configuration file
 // 3. Default values (lowest priority)
 //
-// This function implements enterprise-grade configuration loading with
-// comprehensive error handling and validation.
-func Load() *Config {
+// The loaded configuration is validated; the returned error lists every
+// problem found rather than only the first.
+func Load() (*Config, error) {
 	cfg := &Config{}
 
 	// Set up Viper configuration
@@ -475,18 +1137,18 @@ func Load() *Config {
 
 	// Unmarshal configuration into struct
 	if err := viper.Unmarshal(cfg); err != nil {
-		zap.L().Fatal("Unable to decode configuration", zap.Error(err))
+		return nil, fmt.Errorf("unable to decode configuration: %w", err)
 	}
 
 	// Apply environment variable overrides
 	applyEnvironmentOverrides(cfg)
 
 	// Validate configuration
-	if err := validateConfig(cfg); err != nil {
-		zap.L().Fatal("Configuration validation failed", zap.Error(err))
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
 
-	return cfg
+	return cfg, nil
 }
 
 // setDefaults sets default values for all configuration options
@@ -497,6 +1159,18 @@ func setDefaults() {
 	viper.SetDefault("server.read_timeout", "30s")
 	viper.SetDefault("server.write_timeout", "30s")
 	viper.SetDefault("server.idle_timeout", "60s")
+	viper.SetDefault("server.grpc.enabled", false)
+	viper.SetDefault("server.grpc.host", "0.0.0.0")
+	viper.SetDefault("server.grpc.port", "9095")
+	viper.SetDefault("server.grpc.max_recv_msg_size", 16*1024*1024)
+	viper.SetDefault("server.stream.enabled", false)
+	viper.SetDefault("server.stream.max_streams", 10)
+	viper.SetDefault("server.stream.rate_limit", 100)
+	viper.SetDefault("server.stream.heartbeat", "15s")
+	viper.SetDefault("server.stream.group_prefix", "event-bus-stream")
+	viper.SetDefault("server.startup.timeout", "2m")
+	viper.SetDefault("server.startup.initial_backoff", "500ms")
+	viper.SetDefault("server.startup.max_backoff", "15s")
 
 	// Environment defaults
 	viper.SetDefault("environment", "development")
@@ -505,7 +1179,39 @@ func setDefaults() {
 	// Kafka defaults
 	viper.SetDefault("kafka.brokers", []string{"localhost:9092"})
 	viper.SetDefault("kafka.client_id", "event-bus-service")
+	viper.SetDefault("kafka.security.protocol", "PLAINTEXT")
 	viper.SetDefault("kafka.version", "2.8.0")
+	viper.SetDefault("kafka.event_format", "native")
+	viper.SetDefault("kafka.health.timeout", "2s")
+	viper.SetDefault("kafka.health.cache_ttl", "5s")
+	viper.SetDefault("kafka.health.min_healthy_brokers", 1)
+	viper.SetDefault("kafka.health.canary_enabled", false)
+	viper.SetDefault("kafka.health.canary_topic", "_healthcheck")
+	viper.SetDefault("kafka.stats.enabled", true)
+	viper.SetDefault("kafka.stats.reservoir_size", 1024)
+	viper.SetDefault("kafka.stats.rate_window", "1m")
+	viper.SetDefault("kafka.stats.log_size_interval", "1m")
+	viper.SetDefault("kafka.stats.top_topics", 10)
+	viper.SetDefault("kafka.stats.max_topics", 1000)
+	viper.SetDefault("kafka.failover.enabled", false)
+	viper.SetDefault("kafka.failover.check_interval", "5s")
+	viper.SetDefault("kafka.failover.grace_period", "30s")
+	viper.SetDefault("kafka.failover.failback_after", "2m")
+	viper.SetDefault("kafka.failover.switchover_policy", "buffer")
+	viper.SetDefault("kafka.failover.event_topic", "app.kafka.failover")
+	viper.SetDefault("kafka.topics.auto_provision", false)
+	viper.SetDefault("kafka.topics.defaults.partitions", 3)
+	viper.SetDefault("kafka.topics.defaults.replication_factor", 1)
+	viper.SetDefault("kafka.topics.defaults.cleanup_policy", "delete")
+	viper.SetDefault("kafka.encryption.kms", "local")
+	viper.SetDefault("kafka.encryption.data_key_ttl", "1h")
+	viper.SetDefault("kafka.encryption.local.secret_prefix", "event-encryption")
+	viper.SetDefault("kafka.encryption.local.secrets.provider", "environment")
+	viper.SetDefault("kafka.encryption.aws.region", "us-east-1")
+	viper.SetDefault("kafka.schema_registry.timeout", "5s")
+	viper.SetDefault("kafka.schema_registry.compatibility.enabled", false)
+	viper.SetDefault("kafka.schema_registry.compatibility.mode", "auto_register")
+	viper.SetDefault("kafka.schema_registry.compatibility.cache_ttl", "1m")
 	viper.SetDefault("kafka.producer.required_acks", 1)
 	viper.SetDefault("kafka.producer.timeout", "30s")
 	viper.SetDefault("kafka.producer.compression", "snappy")
@@ -515,6 +1221,14 @@ func setDefaults() {
 	viper.SetDefault("kafka.producer.flush_frequency", "5s")
 	viper.SetDefault("kafka.producer.flush_messages", 100)
 	viper.SetDefault("kafka.producer.idempotent", true)
+	viper.SetDefault("kafka.producer.large_message_mode", "reject")
+	viper.SetDefault("kafka.producer.claim_check.prefix", "claim-checks")
+	viper.SetDefault("kafka.producer.backpressure.max_in_flight_messages", 10000)
+	viper.SetDefault("kafka.producer.backpressure.max_in_flight_bytes", 64<<20)
+	viper.SetDefault("kafka.producer.backpressure.retry_after", "1s")
+	viper.SetDefault("kafka.producer.publish_mode", "sync")
+	viper.SetDefault("kafka.producer.async_queue_size", 10000)
+	viper.SetDefault("kafka.producer.async_workers", 8)
 	viper.SetDefault("kafka.consumer.group_id", "event-bus-service-group")
 	viper.SetDefault("kafka.consumer.auto_offset_reset", "earliest")
 	viper.SetDefault("kafka.consumer.enable_auto_commit", true)
@@ -528,11 +1242,13 @@ func setDefaults() {
 	viper.SetDefault("kafka.consumer.max_wait_time", "250ms")
 	viper.SetDefault("kafka.consumer.channel_buffer_size", 256)
 	viper.SetDefault("kafka.consumer.return_errors", true)
+	viper.SetDefault("kafka.consumer.rebalance_strategy", "range")
 
 	// Debezium defaults
 	viper.SetDefault("debezium.enabled", false)
 	viper.SetDefault("debezium.connect.url", "http://localhost:8083")
 	viper.SetDefault("debezium.connect.timeout", "30s")
+	viper.SetDefault("debezium.secrets.provider", "environment")
 
 	// Database defaults
 	viper.SetDefault("databases.default.type", "postgres")
@@ -544,6 +1260,15 @@ func setDefaults() {
 	viper.SetDefault("databases.default.max_idle_conns", 5)
 	viper.SetDefault("databases.default.conn_max_lifetime", "1h")
 	viper.SetDefault("databases.default.conn_max_idle_time", "30m")
+	viper.SetDefault("databases.event_store.type", "postgres")
+	viper.SetDefault("databases.event_store.host", "localhost")
+	viper.SetDefault("databases.event_store.port", 5432)
+	viper.SetDefault("databases.event_store.name", "eventbus")
+	viper.SetDefault("databases.event_store.username", "eventbus")
+	viper.SetDefault("databases.event_store.ssl_mode", "disable")
+	viper.SetDefault("databases.event_store.max_open_conns", 10)
+	viper.SetDefault("databases.event_store.max_idle_conns", 5)
+	viper.SetDefault("databases.event_store.conn_max_lifetime", "1h")
 
 	// Redis defaults
 	viper.SetDefault("redis.enabled", false)
@@ -559,8 +1284,12 @@ func setDefaults() {
 	viper.SetDefault("security.jwt.issuer", "event-bus-service")
 	viper.SetDefault("security.jwt.expires_in", "24h")
 	viper.SetDefault("security.api_keys.enabled", false)
+	viper.SetDefault("security.admin_keys.enabled", false)
 	viper.SetDefault("security.event_signing.enabled", false)
 	viper.SetDefault("security.event_signing.algorithm", "HMAC-SHA256")
+	viper.SetDefault("security.publish_acl.enabled", false)
+	viper.SetDefault("security.publish_acl.default_action", "allow")
+	viper.SetDefault("security.publish_acl.protected_topics", []string{"cdc.*", "*.dlq"})
 
 	// Observability defaults
 	viper.SetDefault("observability.metrics.enabled", true)
@@ -574,6 +1303,10 @@ func setDefaults() {
 	viper.SetDefault("observability.tracing.sample_rate", 0.1)
 	viper.SetDefault("observability.health.check_interval", "30s")
 	viper.SetDefault("observability.health.timeout", "10s")
+	viper.SetDefault("observability.errors.provider", "noop")
+	viper.SetDefault("observability.errors.burst", 10)
+	viper.SetDefault("observability.errors.interval", "1m")
+	viper.SetDefault("observability.errors.sample_rate", 0)
 
 	// Event processing defaults
 	viper.SetDefault("event_processing.workers", 5)
@@ -583,13 +1316,113 @@ func setDefaults() {
 	viper.SetDefault("event_processing.retry_backoff", "1s")
 	viper.SetDefault("event_processing.dead_letter_queue.enabled", true)
 	viper.SetDefault("event_processing.dead_letter_queue.topic_name", "dead-letter-queue")
-	viper.SetDefault("event_processing.dead_letter_queue.ttl", "7d")
+	viper.SetDefault("event_processing.dead_letter_queue.ttl", "168h")
 	viper.SetDefault("event_processing.deduplication.enabled", true)
 	viper.SetDefault("event_processing.deduplication.window", "5m")
 	viper.SetDefault("event_processing.deduplication.storage", "memory")
 	viper.SetDefault("event_processing.ordering.enabled", false)
 	viper.SetDefault("event_processing.ordering.buffer_size", 1000)
 	viper.SetDefault("event_processing.ordering.max_wait_time", "1s")
+	viper.SetDefault("event_processing.response_projection.enabled", false)
+	viper.SetDefault("event_processing.response_projection.topics", []string{"app.form.response.created", "app.form.response.updated", "app.form.response.purged", "app.form.responses.deleted"})
+	viper.SetDefault("event_processing.response_projection.group_id", "event-bus-response-projection")
+	viper.SetDefault("event_processing.response_projection.batch_size", 500)
+	viper.SetDefault("event_processing.response_projection.flush_interval", "1s")
+	viper.SetDefault("event_processing.funnel_projection.enabled", false)
+	viper.SetDefault("event_processing.funnel_projection.topics", []string{"app.form.funnel.stage", "app.form.response.created"})
+	viper.SetDefault("event_processing.funnel_projection.group_id", "event-bus-funnel-projection")
+	viper.SetDefault("event_processing.funnel_projection.batch_size", 500)
+	viper.SetDefault("event_processing.funnel_projection.flush_interval", "1s")
+	viper.SetDefault("event_processing.funnel_projection.session_retention", "168h")
+	viper.SetDefault("event_processing.event_store.enabled", false)
+	viper.SetDefault("event_processing.event_store.group_id", "event-bus-event-store")
+	viper.SetDefault("event_processing.event_store.batch_size", 500)
+	viper.SetDefault("event_processing.event_store.flush_interval", "1s")
+	viper.SetDefault("event_processing.event_store.retention", "168h")
+	viper.SetDefault("event_processing.event_store.purge_interval", "1h")
+
+	viper.SetDefault("event_processing.scheduled_events.enabled", false)
+	viper.SetDefault("event_processing.scheduled_events.poll_interval", "5s")
+	viper.SetDefault("event_processing.scheduled_events.batch_size", 100)
+	viper.SetDefault("event_processing.scheduled_events.claim_timeout", "1m")
+	viper.SetDefault("event_processing.scheduled_events.retry_backoff", "30s")
+	viper.SetDefault("event_processing.scheduled_events.max_delay", "720h")
+	viper.SetDefault("event_processing.catalog.enabled", false)
+	viper.SetDefault("event_processing.catalog.flush_interval", "30s")
+	viper.SetDefault("event_processing.catalog.stale_after", "720h")
+	viper.SetDefault("event_processing.catalog.sample_max_bytes", 4096)
+	viper.SetDefault("event_processing.catalog.max_event_types", 1000)
+	viper.SetDefault("event_processing.notifications.enabled", false)
+	viper.SetDefault("event_processing.notifications.topics", []string{"app.form.response.created", "app.form.published", "app.form.notification.test", "app.form.notification.channel_test", "app.form.report.ready", "app.form.throttle.engaged", "app.form.throttle.released"})
+	viper.SetDefault("event_processing.notifications.group_id", "event-bus-notifications")
+	viper.SetDefault("event_processing.notifications.batch_size", 50)
+	viper.SetDefault("event_processing.notifications.flush_interval", "1s")
+	viper.SetDefault("event_processing.notifications.sender", "console")
+	viper.SetDefault("event_processing.notifications.from", "X-Form <no-reply@xform.local>")
+	viper.SetDefault("event_processing.notifications.smtp.port", 587)
+	viper.SetDefault("event_processing.notifications.smtp.timeout", "10s")
+	viper.SetDefault("event_processing.notifications.form_service_url", "http://form-service:8080")
+	viper.SetDefault("event_processing.notifications.settings_cache_ttl", "1m")
+	viper.SetDefault("event_processing.notifications.app_url", "http://localhost:3000")
+	viper.SetDefault("event_processing.notifications.rate_limit", 20)
+	viper.SetDefault("event_processing.notifications.rate_window", "1h")
+	viper.SetDefault("event_processing.notifications.retry_attempts", 3)
+	viper.SetDefault("event_processing.notifications.retry_backoff", "2s")
+	viper.SetDefault("event_processing.notifications.dead_letter_topic", "app.notifications.dlq")
+	viper.SetDefault("event_processing.notifications.channel_timeout", "10s")
+
+	viper.SetDefault("event_processing.privacy.enabled", false)
+	viper.SetDefault("event_processing.privacy.request_topics", []string{"app.privacy.erasure.requested", "app.privacy.export.requested"})
+	viper.SetDefault("event_processing.privacy.completion_topics", []string{"app.privacy.erasure.completed", "app.privacy.export.completed"})
+	viper.SetDefault("event_processing.privacy.group_id", "event-bus-privacy")
+	viper.SetDefault("event_processing.privacy.batch_size", 10)
+	viper.SetDefault("event_processing.privacy.flush_interval", "1s")
+	viper.SetDefault("event_processing.privacy.form_service_url", "http://form-service:8080")
+	viper.SetDefault("event_processing.privacy.collaboration_service_url", "http://collaboration-service:8080")
+	viper.SetDefault("event_processing.privacy.gateway_url", "http://api-gateway:8000")
+	viper.SetDefault("event_processing.privacy.timeout", "30s")
+	viper.SetDefault("event_processing.privacy.retry_attempts", 3)
+	viper.SetDefault("event_processing.privacy.retry_backoff", "2s")
+
+	viper.SetDefault("event_processing.audit.enabled", false)
+	viper.SetDefault("event_processing.audit.topics", []string{"audit-log"})
+	viper.SetDefault("event_processing.audit.group_id", "event-bus-audit")
+	viper.SetDefault("event_processing.audit.batch_size", 100)
+	viper.SetDefault("event_processing.audit.flush_interval", "1s")
+
+	viper.SetDefault("event_processing.anomaly.enabled", false)
+	viper.SetDefault("event_processing.anomaly.topics", []string{"app.form.response.created"})
+	viper.SetDefault("event_processing.anomaly.group_id", "event-bus-anomaly")
+	viper.SetDefault("event_processing.anomaly.batch_size", 500)
+	viper.SetDefault("event_processing.anomaly.flush_interval", "1s")
+	viper.SetDefault("event_processing.anomaly.window", "5m")
+	viper.SetDefault("event_processing.anomaly.baseline_period", "24h")
+	viper.SetDefault("event_processing.anomaly.evaluation_interval", "1m")
+	viper.SetDefault("event_processing.anomaly.key_prefix", "eventbus:anomaly:")
+	viper.SetDefault("event_processing.anomaly.form_service_url", "http://form-service:8080")
+	viper.SetDefault("event_processing.anomaly.settings_cache_ttl", "1m")
+
+	viper.SetDefault("event_processing.live_stats.enabled", false)
+	viper.SetDefault("event_processing.live_stats.topics", []string{"app.form.response.created"})
+	viper.SetDefault("event_processing.live_stats.group_id", "event-bus-live-stats")
+	viper.SetDefault("event_processing.live_stats.batch_size", 500)
+	viper.SetDefault("event_processing.live_stats.flush_interval", "250ms")
+	viper.SetDefault("event_processing.cache_invalidation.enabled", false)
+	viper.SetDefault("event_processing.cache_invalidation.topics", []string{"app.form.updated", "app.form.published", "app.form.deleted", "app.form.archived", "app.form.transferred"})
+	viper.SetDefault("event_processing.cache_invalidation.group_id", "event-bus-cache-invalidation")
+	viper.SetDefault("event_processing.cache_invalidation.channel", "gateway:cache:invalidations")
+	viper.SetDefault("event_processing.cache_invalidation.batch_size", 500)
+	viper.SetDefault("event_processing.cache_invalidation.flush_interval", "100ms")
+
+	for name, typ := range map[string]string{
+		"cdc-processor":       "cdc",
+		"form-processor":      "form",
+		"response-processor":  "response",
+		"analytics-processor": "analytics",
+	} {
+		viper.SetDefault("event_processing.processors."+name+".type", typ)
+		viper.SetDefault("event_processing.processors."+name+".enabled", true)
+	}
 
 	// Rate limiting defaults
 	viper.SetDefault("rate_limiting.enabled", true)
@@ -640,6 +1473,9 @@ func applyEnvironmentOverrides(cfg *Config) {
 	if host := os.Getenv("SERVER_HOST"); host != "" {
 		cfg.Server.Host = host
 	}
+	if port := os.Getenv("GRPC_PORT"); port != "" {
+		cfg.Server.GRPC.Port = port
+	}
 
 	// Environment override
 	if env := os.Getenv("ENVIRONMENT"); env != "" {
@@ -653,12 +1489,20 @@ func applyEnvironmentOverrides(cfg *Config) {
 	if brokers := os.Getenv("KAFKA_BROKERS"); brokers != "" {
 		cfg.Kafka.Brokers = strings.Split(brokers, ",")
 	}
+	if brokers := os.Getenv("KAFKA_SECONDARY_BROKERS"); brokers != "" {
+		cfg.Kafka.Failover.SecondaryBrokers = strings.Split(brokers, ",")
+	}
 	if clientID := os.Getenv("KAFKA_CLIENT_ID"); clientID != "" {
 		cfg.Kafka.ClientID = clientID
 	}
 	if groupID := os.Getenv("KAFKA_CONSUMER_GROUP_ID"); groupID != "" {
 		cfg.Kafka.Consumer.GroupID = groupID
 	}
+	if instanceID := os.Getenv("KAFKA_CONSUMER_INSTANCE_ID"); instanceID != "" {
+		cfg.Kafka.Consumer.InstanceID = instanceID
+	}
+	// Instance IDs are derived from the pod, e.g. event-bus-${POD_NAME}
+	cfg.Kafka.Consumer.InstanceID = os.ExpandEnv(cfg.Kafka.Consumer.InstanceID)
 
 	// Security overrides
 	if secret := os.Getenv("JWT_SECRET"); secret != "" {
@@ -672,6 +1516,23 @@ func applyEnvironmentOverrides(cfg *Config) {
 	applyDatabaseOverrides(&cfg.Databases.ResponseDB, "RESPONSE_DATABASE")
 	applyDatabaseOverrides(&cfg.Databases.EventStore, "EVENTSTORE_DATABASE")
 
+	// SMTP overrides, keeping the password out of the config file
+	if smtpHost := os.Getenv("SMTP_HOST"); smtpHost != "" {
+		cfg.EventProcessing.Notifications.SMTP.Host = smtpHost
+	}
+	if smtpUsername := os.Getenv("SMTP_USERNAME"); smtpUsername != "" {
+		cfg.EventProcessing.Notifications.SMTP.Username = smtpUsername
+	}
+	if smtpPassword := os.Getenv("SMTP_PASSWORD"); smtpPassword != "" {
+		cfg.EventProcessing.Notifications.SMTP.Password = smtpPassword
+	}
+	if callbackToken := os.Getenv("PRIVACY_CALLBACK_TOKEN"); callbackToken != "" {
+		cfg.EventProcessing.Privacy.CallbackToken = callbackToken
+	}
+	if serviceToken := os.Getenv("PRIVACY_SERVICE_TOKEN"); serviceToken != "" {
+		cfg.EventProcessing.Privacy.ServiceToken = serviceToken
+	}
+
 	// Redis overrides
 	if redisHost := os.Getenv("REDIS_HOST"); redisHost != "" {
 		cfg.Redis.Host = redisHost
@@ -750,61 +1611,552 @@ func applyDatabaseOverrides(dbConfig *DatabaseConfig, prefix string) {
 	}
 }
 
-// validateConfig validates the loaded configuration
-func validateConfig(cfg *Config) error {
-	// Validate required fields
-	if cfg.Server.Port == "" {
-		return fmt.Errorf("server port is required")
+// Validate checks the configuration, including settings that depend on each
+// other, and returns an error listing every problem found
+func (c *Config) Validate() error {
+	var p problems
+
+	switch c.Environment {
+	case "development", "test", "staging", "production":
+	default:
+		p.addf("environment %q is not one of development, test, staging or production", c.Environment)
+	}
+
+	// Server
+	p.port("server port", c.Server.Port)
+	if c.Server.ReadTimeout <= 0 {
+		p.addf("server read timeout must be positive")
+	}
+	if c.Server.WriteTimeout <= 0 {
+		p.addf("server write timeout must be positive")
+	}
+	if c.Server.IdleTimeout > 0 && c.Server.ReadTimeout >= c.Server.IdleTimeout {
+		p.addf("server read timeout (%s) must be shorter than the idle timeout (%s)", c.Server.ReadTimeout, c.Server.IdleTimeout)
+	}
+	p.tls("server TLS", c.Server.TLS)
+	if c.Server.GRPC.Enabled {
+		p.port("gRPC port", c.Server.GRPC.Port)
+		if c.Server.GRPC.Port == c.Server.Port {
+			p.addf("gRPC port %s is also the server port", c.Server.GRPC.Port)
+		}
+		p.tls("gRPC TLS", c.Server.GRPC.TLS)
+	}
+	if startup := c.Server.Startup; startup.Timeout <= 0 || startup.InitialBackoff <= 0 {
+		p.addf("server startup timeout and initial backoff must be positive")
+	} else if startup.MaxBackoff < startup.InitialBackoff {
+		p.addf("server startup max backoff (%s) must not be shorter than the initial backoff (%s)", startup.MaxBackoff, startup.InitialBackoff)
+	}
+	if admin := c.Security.AdminKeys; admin.Enabled && len(admin.Keys) == 0 {
+		p.addf("admin keys are enabled but none is configured in security.admin_keys")
+	}
+	if stream := c.Server.Stream; stream.Enabled {
+		if !c.Security.APIKeys.Enabled || len(c.Security.APIKeys.Keys) == 0 {
+			p.addf("event stream requires API keys to be enabled and configured in security.api_keys")
+		}
+		if stream.MaxStreams < 1 {
+			p.addf("event stream max streams must be at least 1")
+		}
+		if stream.RateLimit <= 0 || stream.Heartbeat <= 0 {
+			p.addf("event stream rate limit and heartbeat must be positive")
+		}
+		if stream.GroupPrefix == "" {
+			p.addf("event stream group prefix is required")
+		}
+	}
+
+	// Kafka
+	if len(c.Kafka.Brokers) == 0 {
+		p.addf("kafka brokers are required")
+	}
+	for _, broker := range c.Kafka.Brokers {
+		if err := validateBroker(broker); err != nil {
+			p.addf("kafka broker %q: %v", broker, err)
+		}
+	}
+	if c.Kafka.Consumer.GroupID == "" {
+		p.addf("kafka consumer group ID is required")
+	}
+	if err := validateEventFormat(c.Kafka.EventFormat); err != nil {
+		p.add(err)
+	}
+	for topic, format := range c.Kafka.TopicEventFormats {
+		if err := validateEventFormat(format); err != nil {
+			p.addf("topic %s: %v", topic, err)
+		}
+	}
+	p.oneOf("kafka security protocol", c.Kafka.Security.Protocol, "PLAINTEXT", "SASL_PLAINTEXT", "SASL_SSL", "SSL")
+	if strings.HasPrefix(c.Kafka.Security.Protocol, "SASL_") {
+		p.oneOf("kafka SASL mechanism", c.Kafka.Security.SASL.Mechanism, "PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512")
+	}
+	if tls := c.Kafka.Security.TLS; (tls.CertFile == "") != (tls.KeyFile == "") {
+		p.addf("kafka TLS cert file and key file must be set together")
+	}
+	switch c.Kafka.Producer.RequiredAcks {
+	case -1, 0, 1:
+	default:
+		p.addf("kafka producer required acks %d is not one of -1, 0 or 1", c.Kafka.Producer.RequiredAcks)
+	}
+	p.oneOf("kafka producer compression", c.Kafka.Producer.Compression, "", "none", "gzip", "snappy", "lz4", "zstd")
+	if c.Kafka.Producer.MaxMessageBytes < 1 {
+		p.addf("kafka producer max message bytes must be positive")
+	}
+	p.oneOf("kafka producer large message mode", c.Kafka.Producer.LargeMessageMode, "", "reject", "claim_check")
+	if claimCheck := c.Kafka.Producer.ClaimCheck; c.Kafka.Producer.LargeMessageMode == "claim_check" {
+		if claimCheck.Bucket == "" {
+			p.addf("kafka producer claim check bucket is required by the claim_check large message mode")
+		}
+		if claimCheck.AccessKeyID == "" || claimCheck.SecretAccessKey == "" {
+			p.addf("kafka producer claim check access key ID and secret access key are required by the claim_check large message mode")
+		}
+		if claimCheck.Endpoint != "" {
+			p.url("kafka producer claim check endpoint", claimCheck.Endpoint)
+		}
+	}
+	if bp := c.Kafka.Producer.Backpressure; bp.MaxInFlightMessages < 0 || bp.MaxInFlightBytes < 0 || bp.RetryAfter < 0 {
+		p.addf("kafka producer backpressure limits and retry after must not be negative")
+	}
+	p.oneOf("kafka producer publish mode", c.Kafka.Producer.PublishMode, "", "sync", "async")
+	if producer := c.Kafka.Producer; producer.PublishMode == "async" && (producer.AsyncQueueSize < 1 || producer.AsyncWorkers < 1) {
+		p.addf("kafka producer async queue size and workers must be positive in the async publish mode")
+	}
+	p.oneOf("kafka consumer auto offset reset", c.Kafka.Consumer.AutoOffsetReset, "", "earliest", "latest")
+	p.oneOf("kafka consumer isolation level", c.Kafka.Consumer.IsolationLevel, "", "ReadUncommitted", "ReadCommitted")
+	p.oneOf("kafka consumer rebalance strategy", c.Kafka.Consumer.RebalanceStrategy, "", "range", "roundrobin", "sticky", "cooperative-sticky")
+	if instanceID := c.Kafka.Consumer.InstanceID; instanceID != "" && (len(instanceID) > 249 || strings.ContainsAny(instanceID, " \t\n$")) {
+		p.addf("kafka consumer instance ID %q must be at most 249 characters without spaces or unexpanded variables", instanceID)
+	}
+	if consumer := c.Kafka.Consumer; consumer.HeartbeatInterval > 0 && consumer.HeartbeatInterval >= consumer.SessionTimeout {
+		p.addf("kafka consumer heartbeat interval (%s) must be shorter than the session timeout (%s)", consumer.HeartbeatInterval, consumer.SessionTimeout)
+	}
+	if health := c.Kafka.Health; health.Timeout < 0 || health.CacheTTL < 0 || health.MinHealthyBrokers < 0 {
+		p.addf("kafka health timeout, cache TTL and min healthy brokers must not be negative")
+	} else if health.CanaryEnabled && health.CanaryTopic == "" {
+		p.addf("kafka health canary topic is required when the canary is enabled")
+	}
+	if stats := c.Kafka.Stats; stats.Enabled {
+		if stats.ReservoirSize < 1 || stats.RateWindow <= 0 || stats.MaxTopics < 1 {
+			p.addf("kafka stats reservoir size, rate window and max topics must be positive")
+		}
+		if stats.LogSizeInterval < 0 || stats.TopTopics < 0 {
+			p.addf("kafka stats log size interval and top topics must not be negative")
+		}
+	}
+	if failover := c.Kafka.Failover; failover.Enabled {
+		if len(failover.SecondaryBrokers) == 0 {
+			p.addf("kafka failover secondary brokers are required when failover is enabled")
+		}
+		for _, broker := range failover.SecondaryBrokers {
+			if err := validateBroker(broker); err != nil {
+				p.addf("kafka failover secondary broker %q: %v", broker, err)
+			}
+		}
+		if failover.CheckInterval <= 0 || failover.GracePeriod <= 0 || failover.FailbackAfter <= 0 {
+			p.addf("kafka failover check interval, grace period and failback after must be positive")
+		}
+		if failover.SwitchoverTimeout < 0 {
+			p.addf("kafka failover switchover timeout must not be negative")
+		}
+		p.oneOf("kafka failover switchover policy", failover.SwitchoverPolicy, "", "buffer", "fail_fast")
+	}
+	p.topicSpec("kafka topic defaults", c.Kafka.Topics.Defaults)
+	if c.Kafka.Topics.Defaults.Partitions < 1 || c.Kafka.Topics.Defaults.ReplicationFactor < 1 {
+		p.addf("kafka topic defaults need at least 1 partition and a replication factor of at least 1")
+	}
+	for i, spec := range c.Kafka.Topics.Specs {
+		name := fmt.Sprintf("kafka topic spec %d", i+1)
+		if (spec.Name == "") == (spec.Pattern == "") {
+			p.addf("%s needs either a name or a pattern", name)
+		}
+		p.topicSpec(name, spec)
+	}
+	if encryption := c.Kafka.Encryption; c.Kafka.Topics.Encrypted() || encryption.Configured() {
+		p.oneOf("kafka encryption kms", encryption.KMS, "local", "aws")
+		if encryption.DataKeyTTL <= 0 {
+			p.addf("kafka encryption data key TTL must be positive")
+		}
+		switch encryption.KMS {
+		case "local":
+			if !slices.Contains(encryption.Local.Keys, encryption.Local.ActiveKey) {
+				p.addf("kafka encryption local active key %q must be one of the keys", encryption.Local.ActiveKey)
+			}
+			p.oneOf("kafka encryption local secrets provider", encryption.Local.Secrets.Provider, "", "environment", "file")
+		case "aws":
+			if encryption.AWS.KeyID == "" {
+				p.addf("kafka encryption aws key ID is required by the aws KMS")
+			}
+			if encryption.AWS.AccessKeyID == "" || encryption.AWS.SecretAccessKey == "" {
+				p.addf("kafka encryption aws access key ID and secret access key are required by the aws KMS")
+			}
+			if encryption.AWS.Endpoint != "" {
+				p.url("kafka encryption aws endpoint", encryption.AWS.Endpoint)
+			}
+		}
+	}
+	if c.Kafka.SchemaRegistry.Enabled {
+		if len(c.Kafka.SchemaRegistry.URLs) == 0 {
+			p.addf("schema registry URLs are required when the schema registry is enabled")
+		}
+		for _, raw := range c.Kafka.SchemaRegistry.URLs {
+			p.url("schema registry URL", raw)
+		}
+		if c.Kafka.SchemaRegistry.Timeout <= 0 {
+			p.addf("schema registry timeout must be positive")
+		}
+	}
+	if compat := c.Kafka.SchemaRegistry.Compatibility; compat.Enabled {
+		if !c.Kafka.SchemaRegistry.Enabled {
+			p.addf("schema compatibility requires the schema registry")
+		}
+		if compat.Mode != "auto_register" && compat.Mode != "strict" {
+			p.addf("schema compatibility mode %q is not auto_register or strict", compat.Mode)
+		}
+		if compat.CacheTTL < 0 {
+			p.addf("schema compatibility cache TTL must not be negative")
+		}
+	}
+
+	// Debezium
+	if c.Debezium.Enabled {
+		p.oneOf("debezium secrets provider", c.Debezium.Secrets.Provider, "environment", "file")
+		if c.Debezium.Secrets.Provider == "file" && c.Debezium.Secrets.Path == "" {
+			p.addf("debezium secrets path is required by the file provider")
+		}
+	}
+
+	// Security
+	if c.Security.JWT.Secret == "" && c.IsProduction() {
+		p.addf("JWT secret is required in production environment")
+	}
+	if acl := c.Security.PublishACL; acl.Enabled {
+		p.oneOf("publish ACL default action", acl.DefaultAction, "allow", "deny")
+		for _, pattern := range acl.ProtectedTopics {
+			if pattern == "" {
+				p.addf("publish ACL protected topic patterns must not be empty")
+			}
+		}
+		for i, rule := range acl.Rules {
+			kind, name, _ := strings.Cut(rule.Principal, ":")
+			if (kind != "san" && kind != "api_key" && kind != "svc") || name == "" {
+				p.addf("publish ACL rule %d: principal %q is not san:<name>, api_key:<name> or svc:<name>", i+1, rule.Principal)
+			}
+			if len(rule.Allow) == 0 && len(rule.Deny) == 0 {
+				p.addf("publish ACL rule %d: allow or deny topics are required", i+1)
+			}
+			for _, pattern := range append(append([]string(nil), rule.Allow...), rule.Deny...) {
+				if pattern == "" {
+					p.addf("publish ACL rule %d: topic patterns must not be empty", i+1)
+				}
+			}
+		}
+	}
+
+	// Event processing
+	if c.EventProcessing.Workers < 1 {
+		p.addf("event processing workers must be at least 1")
+	}
+	if c.EventProcessing.BatchSize < 1 {
+		p.addf("event processing batch size must be at least 1")
+	}
+	if projection := c.EventProcessing.ResponseProjection; projection.Enabled {
+		if len(projection.Topics) == 0 {
+			p.addf("response projection topics are required when the projection is enabled")
+		}
+	}
+	if projection := c.EventProcessing.FunnelProjection; projection.Enabled {
+		if len(projection.Topics) == 0 || projection.GroupID == "" {
+			p.addf("funnel projection topics and group ID are required when the projection is enabled")
+		}
+		// Beacon stages are only accepted for 2 days
+		if projection.SessionRetention < 48*time.Hour {
+			p.addf("funnel projection session retention must be at least 48h")
+		}
+	}
+	if sink := c.EventProcessing.EventStore; sink.Enabled {
+		if sink.GroupID == "" {
+			p.addf("event store group ID is required when the event store is enabled")
+		}
+		if sink.Retention <= 0 || sink.PurgeInterval <= 0 {
+			p.addf("event store retention and purge interval must be positive")
+		}
+	}
+	if notifications := c.EventProcessing.Notifications; notifications.Enabled {
+		if len(notifications.Topics) == 0 || notifications.GroupID == "" {
+			p.addf("notification topics and group ID are required when notifications are enabled")
+		}
+		p.oneOf("notification sender", notifications.Sender, "smtp", "console")
+		if _, err := mail.ParseAddress(notifications.From); err != nil {
+			p.addf("notification from address %q is invalid", notifications.From)
+		}
+		if notifications.Sender == "smtp" {
+			if notifications.SMTP.Host == "" {
+				p.addf("SMTP host is required by the smtp notification sender")
+			}
+			if notifications.SMTP.Port <= 0 || notifications.SMTP.Port > 65535 {
+				p.addf("SMTP port %d is out of range", notifications.SMTP.Port)
+			}
+		}
+		p.url("notification form service URL", notifications.FormServiceURL)
+		p.url("notification app URL", notifications.AppURL)
+		if notifications.RateLimit < 1 || notifications.RateWindow <= 0 {
+			p.addf("notification rate limit must be at least 1 per positive window")
+		}
+		if notifications.RetryAttempts < 0 || notifications.RetryBackoff < 0 {
+			p.addf("notification retry attempts and backoff must not be negative")
+		}
+		if notifications.DeadLetterTopic == "" {
+			p.addf("notification dead letter topic is required when notifications are enabled")
+		}
+		if notifications.ChannelTimeout <= 0 {
+			p.addf("notification channel timeout must be positive")
+		}
+	}
+	if privacy := c.EventProcessing.Privacy; privacy.Enabled {
+		if len(privacy.RequestTopics) == 0 || len(privacy.CompletionTopics) == 0 || privacy.GroupID == "" {
+			p.addf("privacy request topics, completion topics and group ID are required when the privacy worker is enabled")
+		}
+		p.url("privacy form service URL", privacy.FormServiceURL)
+		p.url("privacy collaboration service URL", privacy.CollaborationServiceURL)
+		p.url("privacy gateway URL", privacy.GatewayURL)
+		if privacy.CallbackToken == "" {
+			p.addf("privacy callback token is required when the privacy worker is enabled")
+		}
+		if privacy.Timeout <= 0 || privacy.RetryAttempts < 0 || privacy.RetryBackoff < 0 {
+			p.addf("privacy timeout must be positive, and retry attempts and backoff must not be negative")
+		}
+	}
+	if audit := c.EventProcessing.Audit; audit.Enabled {
+		if len(audit.Topics) == 0 || audit.GroupID == "" {
+			p.addf("audit topics and group ID are required when the audit log is enabled")
+		}
+		if audit.BatchSize <= 0 {
+			p.addf("audit batch size must be positive")
+		}
+	}
+	if scheduled := c.EventProcessing.ScheduledEvents; scheduled.Enabled {
+		if scheduled.PollInterval <= 0 || scheduled.BatchSize <= 0 || scheduled.MaxDelay <= 0 || scheduled.RetryBackoff < 0 {
+			p.addf("scheduled events poll interval, batch size and max delay must be positive, and retry backoff must not be negative")
+		}
+		if scheduled.ClaimTimeout <= c.Kafka.Producer.Timeout {
+			p.addf("scheduled events claim timeout must exceed the kafka producer timeout")
+		}
+	}
+	if catalog := c.EventProcessing.Catalog; catalog.Enabled {
+		if catalog.FlushInterval <= 0 || catalog.StaleAfter <= 0 || catalog.SampleMaxBytes <= 0 || catalog.MaxEventTypes <= 0 {
+			p.addf("catalog flush interval, stale after, sample max bytes and max event types must be positive")
+		}
+		for i, registration := range catalog.Registrations {
+			if registration.Service == "" || len(registration.Produces)+len(registration.Consumes) == 0 {
+				p.addf("catalog registration %d: a service and the event types it produces or consumes are required", i+1)
+			}
+		}
+	}
+	if anomaly := c.EventProcessing.Anomaly; anomaly.Enabled {
+		if len(anomaly.Topics) == 0 || anomaly.GroupID == "" {
+			p.addf("anomaly topics and group ID are required when the anomaly detector is enabled")
+		}
+		if !c.Redis.Enabled {
+			p.addf("redis must be enabled for the anomaly detector to count submissions")
+		}
+		if anomaly.Window < time.Minute || anomaly.BaselinePeriod < 2*anomaly.Window {
+			p.addf("anomaly window must be at least 1m and the baseline period at least twice as long")
+		}
+		if anomaly.EvaluationInterval <= 0 || anomaly.SettingsCacheTTL < 0 {
+			p.addf("anomaly evaluation interval must be positive and the settings cache TTL not negative")
+		}
+		p.url("anomaly form service URL", anomaly.FormServiceURL)
+	}
+	if live := c.EventProcessing.LiveStats; live.Enabled {
+		if len(live.Topics) == 0 || live.GroupID == "" {
+			p.addf("live stats topics and group ID are required when live stats are enabled")
+		}
+		if !c.Redis.Enabled {
+			p.addf("redis must be enabled to publish live stats")
+		}
+	}
+	if invalidation := c.EventProcessing.CacheInvalidation; invalidation.Enabled {
+		if len(invalidation.Topics) == 0 || invalidation.GroupID == "" || invalidation.Channel == "" {
+			p.addf("cache invalidation topics, group ID and channel are required when cache invalidation is enabled")
+		}
+		if invalidation.BatchSize < 1 || invalidation.FlushInterval <= 0 {
+			p.addf("cache invalidation batch size and flush interval must be positive")
+		}
+		if !c.Redis.Enabled {
+			p.addf("redis must be enabled to publish cache invalidations")
+		}
+	}
+	if c.EventProcessing.ResponseProjection.Enabled || c.EventProcessing.FunnelProjection.Enabled || c.EventProcessing.EventStore.Enabled ||
+		c.EventProcessing.Privacy.Enabled || c.EventProcessing.Audit.Enabled ||
+		c.EventProcessing.ScheduledEvents.Enabled || c.EventProcessing.Catalog.Enabled {
+		p.database("event store database", &c.Databases.EventStore)
+	}
+
+	// Databases and Redis
+	p.database("default database", &c.Databases.Default)
+	if c.Redis.Enabled {
+		if c.Redis.Port <= 0 || c.Redis.Port > 65535 {
+			p.addf("redis port %d is out of range", c.Redis.Port)
+		}
+		p.tls("redis TLS", c.Redis.TLS)
+	}
+
+	// Observability
+	if c.Observability.Metrics.Enabled && c.Observability.Metrics.Port != "" {
+		p.port("metrics port", c.Observability.Metrics.Port)
+	}
+	p.oneOf("log level", c.Observability.Logging.Level, "debug", "info", "warn", "error")
+
+	// Error reporting
+	errs := c.Observability.Errors
+	p.oneOf("error reporting provider", errs.Provider, "noop", "sentry", "otel")
+	if errs.Provider == "sentry" && errs.DSN == "" {
+		p.addf("error reporting provider sentry needs a DSN")
+	}
+	if errs.Burst < 1 || errs.Interval <= 0 {
+		p.addf("error reporting burst and interval must be positive")
+	}
+	if errs.SampleRate < 0 || errs.SampleRate > 1 {
+		p.addf("error reporting sample rate must be from 0 to 1")
+	}
+
+	return p.err()
+}
+
+// problems collects configuration errors so they can be reported together
+type problems []error
+
+func (p *problems) add(err error) {
+	*p = append(*p, err)
+}
+
+func (p *problems) addf(format string, args ...interface{}) {
+	p.add(fmt.Errorf(format, args...))
+}
+
+func (p problems) err() error {
+	return errors.Join(p...)
+}
+
+// port checks a port setting is a number from 1 to 65535
+func (p *problems) port(name, value string) {
+	if value == "" {
+		p.addf("%s is required", name)
+		return
+	}
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		p.addf("%s %q is not a port from 1 to 65535", name, value)
 	}
+}
 
-	if len(cfg.Kafka.Brokers) == 0 {
-		return fmt.Errorf("kafka brokers are required")
+// tls checks an enabled TLS configuration names its certificate and key
+func (p *problems) tls(name string, tls TLSConfig) {
+	if !tls.Enabled {
+		return
+	}
+	if tls.CertFile == "" {
+		p.addf("%s cert file is required when TLS is enabled", name)
+	}
+	if tls.KeyFile == "" {
+		p.addf("%s key file is required when TLS is enabled", name)
 	}
+}
 
-	if cfg.Kafka.Consumer.GroupID == "" {
-		return fmt.Errorf("kafka consumer group ID is required")
+// oneOf checks value is one of allowed
+func (p *problems) oneOf(name, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
 	}
+	p.addf("%s %q is not supported (expected %s)", name, value, strings.Join(nonEmpty(allowed), ", "))
+}
 
-	if cfg.Security.JWT.Secret == "" && cfg.Environment == "production" {
-		return fmt.Errorf("JWT secret is required in production environment")
+// url checks raw is an absolute URL
+func (p *problems) url(name, raw string) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		p.addf("%s %q is not an absolute URL", name, raw)
 	}
+}
 
-	// Validate event processing configuration
-	if cfg.EventProcessing.Workers < 1 {
-		return fmt.Errorf("event processing workers must be at least 1")
+// database checks the connection settings of a database
+func (p *problems) database(name string, dbConfig *DatabaseConfig) {
+	if err := validateDatabaseConfig(dbConfig, name); err != nil {
+		p.add(err)
 	}
+}
 
-	if cfg.EventProcessing.BatchSize < 1 {
-		return fmt.Errorf("event processing batch size must be at least 1")
+func (p *problems) topicSpec(name string, spec TopicSpec) {
+	if _, err := path.Match(spec.Pattern, ""); err != nil {
+		p.addf("%s pattern %q is malformed", name, spec.Pattern)
+	}
+	if spec.Partitions < 0 {
+		p.addf("%s partitions must not be negative", name)
+	}
+	if spec.ReplicationFactor < 0 {
+		p.addf("%s replication factor must not be negative", name)
 	}
+	if spec.RetentionMs < -1 {
+		p.addf("%s retention_ms must be -1, 0 or positive", name)
+	}
+	p.oneOf(name+" cleanup policy", spec.CleanupPolicy, "", "delete", "compact", "compact,delete")
+}
 
-	// Validate database configurations
-	if err := validateDatabaseConfig(&cfg.Databases.Default, "default database"); err != nil {
-		return err
+func nonEmpty(values []string) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != "" {
+			out = append(out, v)
+		}
 	}
+	return out
+}
 
+// validateBroker checks a broker address is host:port
+func validateBroker(broker string) error {
+	host, port, err := net.SplitHostPort(broker)
+	if err != nil {
+		return err
+	}
+	if host == "" {
+		return fmt.Errorf("missing host")
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("port %q is not a port from 1 to 65535", port)
+	}
 	return nil
 }
 
+// validateEventFormat checks an event_format setting
+func validateEventFormat(format string) error {
+	switch format {
+	case "", "native", "cloudevents":
+		return nil
+	default:
+		return fmt.Errorf("unsupported event format %q (expected native or cloudevents)", format)
+	}
+}
+
 // validateDatabaseConfig validates individual database configuration
 func validateDatabaseConfig(dbConfig *DatabaseConfig, name string) error {
+	var p problems
 	if dbConfig.Host == "" {
-		return fmt.Errorf("%s host is required", name)
+		p.addf("%s host is required", name)
 	}
 
-	if dbConfig.Port <= 0 {
-		return fmt.Errorf("%s port must be greater than 0", name)
+	if dbConfig.Port <= 0 || dbConfig.Port > 65535 {
+		p.addf("%s port %d is out of range", name, dbConfig.Port)
 	}
 
 	if dbConfig.Name == "" {
-		return fmt.Errorf("%s name is required", name)
+		p.addf("%s name is required", name)
 	}
 
 	if dbConfig.Username == "" {
-		return fmt.Errorf("%s username is required", name)
+		p.addf("%s username is required", name)
 	}
 
-	return nil
+	return p.err()
 }
 
 // GetAddress returns the full server address
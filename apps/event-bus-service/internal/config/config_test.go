@@ -0,0 +1,287 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func validConfig() *Config {
+	return &Config{
+		Environment: "development",
+		Server: ServerConfig{
+			Port:         "8090",
+			ReadTimeout:  30 * time.Second,
+			WriteTimeout: 30 * time.Second,
+			IdleTimeout:  60 * time.Second,
+			GRPC:         GRPCConfig{Port: "9095"},
+			Startup:      StartupConfig{Timeout: 2 * time.Minute, InitialBackoff: 500 * time.Millisecond, MaxBackoff: 15 * time.Second},
+		},
+		Kafka: KafkaConfig{
+			Brokers:  []string{"localhost:9092", "kafka-2:9092"},
+			Security: KafkaSecurityConfig{Protocol: "PLAINTEXT"},
+			Producer: KafkaProducerConfig{RequiredAcks: 1, Compression: "snappy", MaxMessageBytes: 1000000},
+			Consumer: KafkaConsumerConfig{
+				GroupID:           "event-bus",
+				AutoOffsetReset:   "earliest",
+				SessionTimeout:    30 * time.Second,
+				HeartbeatInterval: 3 * time.Second,
+			},
+			Topics: KafkaTopicsConfig{
+				Defaults: TopicSpec{Partitions: 3, ReplicationFactor: 1, CleanupPolicy: "delete"},
+			},
+		},
+		Databases: DatabasesConfig{
+			Default: DatabaseConfig{Host: "localhost", Port: 5432, Name: "xform", Username: "postgres"},
+		},
+		Observability: ObservabilityConfig{
+			Metrics: MetricsConfig{Enabled: true, Port: "9090"},
+			Logging: LoggingConfig{Level: "info"},
+			Errors:  ErrorReportingConfig{Provider: "noop", Burst: 10, Interval: time.Minute},
+		},
+		EventProcessing: EventProcessingConfig{Workers: 4, BatchSize: 100},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*Config)
+		want   []string
+	}{
+		{"valid", func(*Config) {}, nil},
+		{"unknown environment", func(c *Config) { c.Environment = "prod" }, []string{`environment "prod"`}},
+		{"port not a number", func(c *Config) { c.Server.Port = "http" }, []string{`server port "http"`}},
+		{"port out of range", func(c *Config) { c.Server.Port = "70000" }, []string{`server port "70000"`}},
+		{"read timeout not below idle timeout", func(c *Config) { c.Server.ReadTimeout = 2 * time.Minute }, []string{"shorter than the idle timeout"}},
+		{"TLS without cert and key", func(c *Config) { c.Server.TLS.Enabled = true }, []string{"server TLS cert file", "server TLS key file"}},
+		{"gRPC on the server port", func(c *Config) {
+			c.Server.GRPC.Enabled = true
+			c.Server.GRPC.Port = "8090"
+		}, []string{"gRPC port 8090 is also the server port"}},
+		{"gRPC disabled is not checked", func(c *Config) { c.Server.GRPC.Port = "" }, nil},
+		{"no brokers", func(c *Config) { c.Kafka.Brokers = nil }, []string{"kafka brokers are required"}},
+		{"broker without port", func(c *Config) { c.Kafka.Brokers = []string{"kafka"} }, []string{`kafka broker "kafka"`}},
+		{"broker with bad port", func(c *Config) { c.Kafka.Brokers = []string{"kafka:x"} }, []string{`kafka broker "kafka:x"`}},
+		{"unknown compression", func(c *Config) { c.Kafka.Producer.Compression = "brotli" }, []string{`compression "brotli"`}},
+		{"no max message bytes", func(c *Config) { c.Kafka.Producer.MaxMessageBytes = 0 }, []string{"max message bytes must be positive"}},
+		{"unknown large message mode", func(c *Config) { c.Kafka.Producer.LargeMessageMode = "split" }, []string{`large message mode "split"`}},
+		{"claim check without bucket", func(c *Config) {
+			c.Kafka.Producer.LargeMessageMode = "claim_check"
+			c.Kafka.Producer.ClaimCheck.Endpoint = "minio:9000"
+		}, []string{"claim check bucket is required", "access key ID and secret access key", `claim check endpoint "minio:9000"`}},
+		{"negative in-flight limit", func(c *Config) { c.Kafka.Producer.Backpressure.MaxInFlightBytes = -1 }, []string{"backpressure limits and retry after must not be negative"}},
+		{"failover without secondary", func(c *Config) {
+			c.Kafka.Failover = KafkaFailoverConfig{Enabled: true, CheckInterval: time.Second, GracePeriod: time.Second, FailbackAfter: time.Second}
+		}, []string{"kafka failover secondary brokers are required"}},
+		{"failover without grace period", func(c *Config) {
+			c.Kafka.Failover = KafkaFailoverConfig{Enabled: true, SecondaryBrokers: []string{"kafka-b"}, CheckInterval: time.Second, SwitchoverPolicy: "drop"}
+		}, []string{`kafka failover secondary broker "kafka-b"`, "grace period and failback after must be positive", `switchover policy "drop"`}},
+		{"failover disabled is not checked", func(c *Config) { c.Kafka.Failover.SecondaryBrokers = []string{"kafka-b"} }, nil},
+		{"stats without reservoir", func(c *Config) {
+			c.Kafka.Stats = KafkaStatsConfig{Enabled: true, RateWindow: time.Minute, MaxTopics: 10, LogSizeInterval: -time.Second}
+		}, []string{"reservoir size, rate window and max topics must be positive", "log size interval and top topics must not be negative"}},
+		{"stats disabled are not checked", func(c *Config) { c.Kafka.Stats.TopTopics = -1 }, nil},
+		{"async publish mode without workers", func(c *Config) {
+			c.Kafka.Producer.PublishMode = "async"
+			c.Kafka.Producer.AsyncWorkers = 0
+		}, []string{"async queue size and workers must be positive"}},
+		{"unknown isolation level", func(c *Config) { c.Kafka.Consumer.IsolationLevel = "read_committed" }, []string{`isolation level "read_committed"`}},
+		{"unknown rebalance strategy", func(c *Config) { c.Kafka.Consumer.RebalanceStrategy = "cooperative" }, []string{`rebalance strategy "cooperative"`}},
+		{"cooperative sticky rebalancing", func(c *Config) {
+			c.Kafka.Consumer.RebalanceStrategy = "cooperative-sticky"
+			c.Kafka.Consumer.InstanceID = "event-bus-2"
+		}, nil},
+		{"unexpanded instance ID", func(c *Config) { c.Kafka.Consumer.InstanceID = "event-bus-${POD}" }, []string{`instance ID "event-bus-${POD}"`}},
+		{"unknown offset reset", func(c *Config) { c.Kafka.Consumer.AutoOffsetReset = "newest" }, []string{`auto offset reset "newest"`}},
+		{"unknown required acks", func(c *Config) { c.Kafka.Producer.RequiredAcks = 2 }, []string{"required acks 2"}},
+		{"unknown security protocol", func(c *Config) { c.Kafka.Security.Protocol = "TLS" }, []string{`security protocol "TLS"`}},
+		{"SASL without mechanism", func(c *Config) { c.Kafka.Security.Protocol = "SASL_SSL" }, []string{`SASL mechanism ""`}},
+		{"kafka TLS key without cert", func(c *Config) { c.Kafka.Security.TLS.KeyFile = "client.key" }, []string{"cert file and key file must be set together"}},
+		{"health canary without topic", func(c *Config) { c.Kafka.Health.CanaryEnabled = true }, []string{"canary topic is required"}},
+		{"startup without timeout", func(c *Config) { c.Server.Startup.Timeout = 0 }, []string{"startup timeout and initial backoff must be positive"}},
+		{"startup max backoff below the initial one", func(c *Config) { c.Server.Startup.MaxBackoff = time.Millisecond }, []string{"startup max backoff"}},
+		{"heartbeat not below session timeout", func(c *Config) { c.Kafka.Consumer.HeartbeatInterval = time.Minute }, []string{"heartbeat interval"}},
+		{"topic spec without name or pattern", func(c *Config) {
+			c.Kafka.Topics.Specs = []TopicSpec{{Partitions: 6}}
+		}, []string{"kafka topic spec 1 needs either a name or a pattern"}},
+		{"topic spec with malformed pattern", func(c *Config) {
+			c.Kafka.Topics.Specs = []TopicSpec{{Pattern: "app.[form"}}
+		}, []string{`pattern "app.[form" is malformed`}},
+		{"topic spec policies", func(c *Config) {
+			c.Kafka.Topics.Specs = []TopicSpec{{Name: "app.form.created", RetentionMs: -2, CleanupPolicy: "archive"}}
+		}, []string{"retention_ms must be -1, 0 or positive", `cleanup policy "archive"`}},
+		{"topic defaults without partitions", func(c *Config) { c.Kafka.Topics.Defaults.Partitions = 0 }, []string{"kafka topic defaults need at least 1 partition"}},
+		{"schema registry URL", func(c *Config) {
+			c.Kafka.SchemaRegistry.Enabled = true
+			c.Kafka.SchemaRegistry.URLs = []string{"registry:8081"}
+		}, []string{`schema registry URL "registry:8081"`, "schema registry timeout must be positive"}},
+		{"schema compatibility without registry", func(c *Config) {
+			c.Kafka.SchemaRegistry.Compatibility = SchemaCompatibilityConfig{Enabled: true, Mode: "full", CacheTTL: -time.Second}
+		}, []string{"requires the schema registry", `mode "full" is not auto_register or strict`, "cache TTL must not be negative"}},
+		{"production without JWT secret", func(c *Config) { c.Environment = "production" }, []string{"JWT secret is required"}},
+		{"projection without event store", func(c *Config) {
+			c.EventProcessing.ResponseProjection = ResponseProjectionConfig{Enabled: true}
+		}, []string{"response projection topics", "event store database host"}},
+		{"funnel projection", func(c *Config) {
+			c.EventProcessing.FunnelProjection = FunnelProjectionConfig{Enabled: true, SessionRetention: time.Hour}
+		}, []string{"funnel projection topics and group ID", "session retention must be at least 48h", "event store database host"}},
+		{"stream without API keys", func(c *Config) {
+			c.Server.Stream = StreamConfig{Enabled: true, MaxStreams: 10, RateLimit: 100, Heartbeat: 15 * time.Second, GroupPrefix: "event-bus-stream"}
+		}, []string{"event stream requires API keys"}},
+		{"stream without limits", func(c *Config) {
+			c.Security.APIKeys = APIKeysConfig{Enabled: true, Keys: map[string]string{"cli": "secret"}}
+			c.Server.Stream = StreamConfig{Enabled: true, GroupPrefix: "event-bus-stream"}
+		}, []string{"max streams must be at least 1", "rate limit and heartbeat must be positive"}},
+		{"admin keys enabled without keys", func(c *Config) {
+			c.Security.AdminKeys = APIKeysConfig{Enabled: true}
+		}, []string{"admin keys are enabled but none is configured"}},
+		{"publish ACL rules", func(c *Config) {
+			c.Security.PublishACL = PublishACLConfig{Enabled: true, DefaultAction: "reject", Rules: []PublishACLRule{
+				{Principal: "svc:response-service", Allow: []string{"form.response.*"}},
+				{Principal: "user:alice", Allow: []string{""}},
+				{Principal: "san:"},
+			}}
+		}, []string{`default action "reject"`, `rule 2: principal "user:alice"`, "rule 2: topic patterns must not be empty", `rule 3: principal "san:"`, "rule 3: allow or deny topics are required"}},
+		{"publish ACL disabled is not checked", func(c *Config) {
+			c.Security.PublishACL = PublishACLConfig{Rules: []PublishACLRule{{Principal: "user:alice"}}}
+		}, nil},
+		{"event store without retention", func(c *Config) {
+			c.EventProcessing.EventStore = EventStoreSinkConfig{Enabled: true, GroupID: "event-store"}
+		}, []string{"event store retention and purge interval must be positive", "event store database host"}},
+		{"scheduled events claim within the producer timeout", func(c *Config) {
+			c.Kafka.Producer.Timeout = 10 * time.Second
+			c.EventProcessing.ScheduledEvents = ScheduledEventsConfig{Enabled: true, PollInterval: 5 * time.Second, ClaimTimeout: 10 * time.Second, MaxDelay: time.Hour}
+		}, []string{"poll interval, batch size and max delay must be positive", "claim timeout must exceed the kafka producer timeout", "event store database host"}},
+		{"catalog registration without event types", func(c *Config) {
+			c.EventProcessing.Catalog = CatalogConfig{Enabled: true, FlushInterval: 30 * time.Second, StaleAfter: 720 * time.Hour,
+				SampleMaxBytes: 4096, MaxEventTypes: 1000, Registrations: []CatalogRegistration{{Service: "form-service"}}}
+		}, []string{"catalog registration 1: a service and the event types", "event store database host"}},
+		{"smtp notifications without server", func(c *Config) {
+			c.EventProcessing.Notifications = NotificationsConfig{
+				Enabled: true, Topics: []string{"app.form.response.created"}, GroupID: "event-bus-notifications",
+				Sender: "smtp", From: "no-reply", FormServiceURL: "form-service:8080", AppURL: "https://xform.example.com",
+			}
+		}, []string{`notification from address "no-reply"`, "SMTP host is required", "SMTP port 0", `form service URL "form-service:8080"`,
+			"rate limit must be at least 1", "dead letter topic is required"}},
+		{"anomaly detector without redis", func(c *Config) {
+			c.EventProcessing.Anomaly = AnomalyConfig{
+				Enabled: true, Topics: []string{"app.form.response.created"}, GroupID: "event-bus-anomaly",
+				Window: 5 * time.Minute, BaselinePeriod: 5 * time.Minute, EvaluationInterval: time.Minute,
+				FormServiceURL: "http://form-service:8080",
+			}
+		}, []string{"redis must be enabled", "baseline period at least twice as long"}},
+		{"live stats without redis", func(c *Config) {
+			c.EventProcessing.LiveStats = LiveStatsConfig{Enabled: true, Topics: []string{"app.form.response.created"}}
+		}, []string{"live stats topics and group ID are required", "redis must be enabled to publish live stats"}},
+		{"cache invalidation without redis", func(c *Config) {
+			c.EventProcessing.CacheInvalidation = CacheInvalidationConfig{Enabled: true, Topics: []string{"app.form.updated"}, GroupID: "event-bus-cache-invalidation"}
+		}, []string{"cache invalidation topics, group ID and channel are required", "batch size and flush interval must be positive", "redis must be enabled to publish cache invalidations"}},
+		{"debezium secrets from an unknown provider", func(c *Config) {
+			c.Debezium = DebeziumConfig{Enabled: true, Secrets: DebeziumSecretsConfig{Provider: "vault"}}
+		}, []string{`debezium secrets provider "vault"`}},
+		{"debezium file secrets without a path", func(c *Config) {
+			c.Debezium = DebeziumConfig{Enabled: true, Secrets: DebeziumSecretsConfig{Provider: "file"}}
+		}, []string{"debezium secrets path is required"}},
+		{"error reporting", func(c *Config) {
+			c.Observability.Errors = ErrorReportingConfig{Provider: "sentry", SampleRate: 2}
+		}, []string{"sentry needs a DSN", "burst and interval must be positive", "sample rate must be from 0 to 1"}},
+		{"unknown error reporter", func(c *Config) { c.Observability.Errors.Provider = "rollbar" }, []string{`error reporting provider "rollbar"`}},
+		{"unknown log level", func(c *Config) { c.Observability.Logging.Level = "verbose" }, []string{`log level "verbose"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.mutate(cfg)
+			err := cfg.Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected errors mentioning %q", tt.want)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error does not mention %q:\n%v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	cfg := validConfig()
+	cfg.Server.Port = ""
+	cfg.Kafka.Producer.Compression = "brotli"
+	cfg.Databases.Default.Host = ""
+	cfg.Databases.Default.Username = ""
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	var joined interface{ Unwrap() []error }
+	if !errors.As(err, &joined) {
+		t.Fatalf("expected an aggregated error, got %T", err)
+	}
+	if lines := strings.Split(err.Error(), "\n"); len(lines) != 4 {
+		t.Errorf("expected 4 problems, got %d:\n%v", len(lines), err)
+	}
+}
+
+func TestTopicSpecFor(t *testing.T) {
+	topics := KafkaTopicsConfig{
+		Defaults: TopicSpec{Partitions: 3, ReplicationFactor: 2, CleanupPolicy: "delete"},
+		Specs: []TopicSpec{
+			{Pattern: "app.form.*", Partitions: 6, RetentionMs: 86400000},
+			{Name: "app.form.snapshots", CleanupPolicy: "compact"},
+		},
+	}
+
+	tests := []struct {
+		topic string
+		want  TopicSpec
+	}{
+		{"app.form.snapshots", TopicSpec{Name: "app.form.snapshots", Partitions: 3, ReplicationFactor: 2, CleanupPolicy: "compact"}},
+		{"app.form.created", TopicSpec{Name: "app.form.created", Partitions: 6, ReplicationFactor: 2, RetentionMs: 86400000, CleanupPolicy: "delete"}},
+		{"app.response.created", TopicSpec{Name: "app.response.created", Partitions: 3, ReplicationFactor: 2, CleanupPolicy: "delete"}},
+	}
+	for _, tt := range tests {
+		if got := topics.SpecFor(tt.topic); got != tt.want {
+			t.Errorf("SpecFor(%q) = %+v, want %+v", tt.topic, got, tt.want)
+		}
+	}
+}
+
+func TestLoadShippedConfig(t *testing.T) {
+	// The database credentials come from the deployment environment
+	t.Setenv("DATABASE_NAME", "eventbus")
+	t.Setenv("DATABASE_USERNAME", "eventbus")
+	t.Setenv("POD_NAME", "event-bus-1")
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir("../../config"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("config/config.yaml is invalid:\n%v", err)
+	}
+	if processor := cfg.EventProcessing.Processors["analytics-processor"]; processor.Type != "analytics" || !processor.Enabled || processor.Settings["default_window"] != "5m" {
+		t.Errorf("analytics-processor = %+v, want it enabled with its settings", processor)
+	}
+	if consumer := cfg.Kafka.Consumer; consumer.InstanceID != "event-bus-1" || consumer.RebalanceStrategy != "cooperative-sticky" {
+		t.Errorf("consumer instance %q with %q, want the pod name with cooperative-sticky", consumer.InstanceID, consumer.RebalanceStrategy)
+	}
+}
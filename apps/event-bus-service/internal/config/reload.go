@@ -0,0 +1,315 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// Reload triggers
+const (
+	ReloadTriggerSignal = "signal"
+	ReloadTriggerFile   = "file"
+	ReloadTriggerAPI    = "api"
+)
+
+// ConfigReloader is implemented by subsystems that apply configuration
+// changes while running. ReloadConfig receives the running configuration
+// with the live settings of the new one applied.
+type ConfigReloader interface {
+	ReloadConfig(cfg *Config) error
+}
+
+// liveSetting is a setting applied without a restart
+type liveSetting struct {
+	key string
+	// apply copies the setting from the loaded configuration
+	apply func(running, loaded *Config)
+}
+
+// liveSettings are the settings applied without a restart. Any other change
+// is reported as requiring one.
+var liveSettings = []liveSetting{
+	{"observability.logging.level", func(running, loaded *Config) {
+		running.Observability.Logging.Level = loaded.Observability.Logging.Level
+	}},
+	{"event_processing.workers", func(running, loaded *Config) {
+		running.EventProcessing.Workers = loaded.EventProcessing.Workers
+	}},
+	{"event_processing.batch_size", func(running, loaded *Config) {
+		running.EventProcessing.BatchSize = loaded.EventProcessing.BatchSize
+	}},
+	{"rate_limiting", func(running, loaded *Config) {
+		running.RateLimiting = loaded.RateLimiting
+	}},
+	{"server.cors.allowed_origins", func(running, loaded *Config) {
+		running.Server.CORS.AllowedOrigins = loaded.Server.CORS.AllowedOrigins
+	}},
+	{"security.publish_acl", func(running, loaded *Config) {
+		running.Security.PublishACL = loaded.Security.PublishACL
+	}},
+}
+
+// ReloadStatus is the outcome of a configuration reload
+type ReloadStatus struct {
+	Trigger string    `json:"trigger"`
+	Actor   string    `json:"actor,omitempty"` // admin key of an api reload
+	Time    time.Time `json:"time"`
+	Success bool      `json:"success"`
+	// Applied lists the settings changed live
+	Applied []string `json:"applied"`
+	// RestartRequired lists changed settings that only take effect on restart
+	RestartRequired []string `json:"restart_required"`
+	Error           string   `json:"error,omitempty"`
+}
+
+// Reloader re-reads the configuration and hands the live settings to the
+// registered subsystems
+type Reloader struct {
+	logger *zap.Logger
+	load   func() (*Config, error)
+
+	mutex     sync.Mutex
+	running   *Config
+	reloaders []namedReloader
+	status    *ReloadStatus
+}
+
+type namedReloader struct {
+	name     string
+	reloader ConfigReloader
+}
+
+// NewReloader creates a reloader for the running configuration, re-reading
+// it with Load
+func NewReloader(running *Config, logger *zap.Logger) *Reloader {
+	return newReloader(running, Load, logger)
+}
+
+func newReloader(running *Config, load func() (*Config, error), logger *zap.Logger) *Reloader {
+	return &Reloader{logger: logger, load: load, running: running}
+}
+
+// Register adds a subsystem notified of live changes
+func (r *Reloader) Register(name string, reloader ConfigReloader) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.reloaders = append(r.reloaders, namedReloader{name: name, reloader: reloader})
+}
+
+// Current returns the running configuration
+func (r *Reloader) Current() *Config {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.running
+}
+
+// Status returns the outcome of the last reload, if any
+func (r *Reloader) Status() (ReloadStatus, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.status == nil {
+		return ReloadStatus{}, false
+	}
+	return *r.status, true
+}
+
+// Reload re-reads the configuration, applies the live settings that changed
+// and logs the changes that need a restart. An invalid configuration is
+// rejected and the running one kept. actor is the admin key that requested
+// the reload, empty when it was not requested through the API.
+func (r *Reloader) Reload(trigger, actor string) ReloadStatus {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	status := ReloadStatus{Trigger: trigger, Actor: actor, Time: time.Now(), Applied: []string{}, RestartRequired: []string{}}
+	defer func() { r.status = &status }()
+
+	loaded, err := r.load()
+	if err != nil {
+		status.Error = err.Error()
+		r.logger.Error("Configuration reload rejected", zap.String("trigger", trigger), zap.Error(err))
+		return status
+	}
+
+	next := *r.running
+	for _, key := range Diff(r.running, loaded) {
+		if setting, ok := findLiveSetting(key); ok {
+			setting.apply(&next, loaded)
+			status.Applied = appendOnce(status.Applied, setting.key)
+		} else {
+			status.RestartRequired = append(status.RestartRequired, key)
+		}
+	}
+	if len(status.RestartRequired) > 0 {
+		r.logger.Warn("Configuration changes require a restart",
+			zap.String("trigger", trigger),
+			zap.Strings("settings", status.RestartRequired))
+	}
+	if len(status.Applied) == 0 {
+		status.Success = true
+		r.logger.Info("Configuration reloaded without live changes", zap.String("trigger", trigger))
+		return status
+	}
+
+	var errs []error
+	for _, nr := range r.reloaders {
+		if err := nr.reloader.ReloadConfig(&next); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", nr.name, err))
+		}
+	}
+	r.running = &next
+
+	if err := errors.Join(errs...); err != nil {
+		status.Error = err.Error()
+		r.logger.Error("Configuration reload partially applied",
+			zap.String("trigger", trigger),
+			zap.Strings("settings", status.Applied),
+			zap.Error(err))
+		return status
+	}
+	status.Success = true
+	r.logger.Info("Configuration reloaded",
+		zap.String("trigger", trigger),
+		zap.Strings("applied", status.Applied))
+	return status
+}
+
+// Watch reloads the configuration on SIGHUP and when the config file
+// changes, until ctx is done
+func (r *Reloader) Watch(ctx context.Context) {
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	defer signal.Stop(hangups)
+
+	var fileChanges <-chan struct{}
+	if file := viper.ConfigFileUsed(); file != "" {
+		changes, err := watchFile(ctx, file, r.logger)
+		if err != nil {
+			r.logger.Warn("Not watching the config file for changes", zap.String("file", file), zap.Error(err))
+		} else {
+			fileChanges = changes
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangups:
+			r.Reload(ReloadTriggerSignal, "")
+		case <-fileChanges:
+			r.Reload(ReloadTriggerFile, "")
+		}
+	}
+}
+
+// fileSettleTime groups the several events an editor saving a file causes
+const fileSettleTime = 500 * time.Millisecond
+
+// watchFile signals changes of file. The directory is watched, as many
+// editors replace the file rather than write to it.
+func watchFile(ctx context.Context, file string, logger *zap.Logger) (<-chan struct{}, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	file = filepath.Clean(file)
+	if err := watcher.Add(filepath.Dir(file)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	changes := make(chan struct{}, 1)
+	go func() {
+		defer watcher.Close()
+		settle := time.NewTimer(fileSettleTime)
+		settle.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) == file && event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+					settle.Reset(fileSettleTime)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logger.Warn("Config file watch error", zap.Error(err))
+			case <-settle.C:
+				select {
+				case changes <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return changes, nil
+}
+
+var configPkgPath = reflect.TypeOf(Config{}).PkgPath()
+
+// Diff returns the keys of the settings that differ between two
+// configurations, e.g. kafka.brokers
+func Diff(a, b *Config) []string {
+	var keys []string
+	diffValues("", reflect.ValueOf(*a), reflect.ValueOf(*b), &keys)
+	return keys
+}
+
+func diffValues(prefix string, a, b reflect.Value, keys *[]string) {
+	// Settings are compared field by field down to the types of other
+	// packages, such as time.Duration
+	if a.Kind() != reflect.Struct || a.Type().PkgPath() != configPkgPath {
+		if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+			*keys = append(*keys, prefix)
+		}
+		return
+	}
+	for i := 0; i < a.NumField(); i++ {
+		field := a.Type().Field(i)
+		name := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		diffValues(name, a.Field(i), b.Field(i), keys)
+	}
+}
+
+func findLiveSetting(key string) (liveSetting, bool) {
+	for _, s := range liveSettings {
+		if key == s.key || strings.HasPrefix(key, s.key+".") {
+			return s, true
+		}
+	}
+	return liveSetting{}, false
+}
+
+func appendOnce(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type recordingReloader struct {
+	configs []*Config
+	err     error
+}
+
+func (r *recordingReloader) ReloadConfig(cfg *Config) error {
+	r.configs = append(r.configs, cfg)
+	return r.err
+}
+
+func TestDiff(t *testing.T) {
+	a := validConfig()
+	b := validConfig()
+	b.Server.Port = "8091"
+	b.Kafka.Brokers = []string{"kafka-3:9092"}
+	b.Kafka.Consumer.SessionTimeout = time.Minute
+	b.Observability.Logging.Level = "debug"
+
+	want := []string{"server.port", "kafka.brokers", "kafka.consumer.session_timeout", "observability.logging.level"}
+	if got := Diff(a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff = %q, want %q", got, want)
+	}
+	if got := Diff(a, validConfig()); len(got) != 0 {
+		t.Errorf("Diff of equal configs = %q", got)
+	}
+}
+
+func TestReloadAppliesLiveSettings(t *testing.T) {
+	running := validConfig()
+	loaded := validConfig()
+	loaded.Observability.Logging.Level = "debug"
+	loaded.EventProcessing.Workers = 8
+	loaded.RateLimiting.RequestsPerSecond = 50
+	loaded.RateLimiting.BurstSize = 100
+	loaded.Server.Port = "8091"
+	loaded.Kafka.Brokers = []string{"kafka-3:9092"}
+
+	reloader := newReloader(running, func() (*Config, error) { return loaded, nil }, zap.NewNop())
+	subsystem := &recordingReloader{}
+	reloader.Register("subsystem", subsystem)
+
+	status := reloader.Reload(ReloadTriggerSignal, "")
+	if !status.Success || status.Trigger != ReloadTriggerSignal {
+		t.Fatalf("status = %+v", status)
+	}
+	if want := []string{"observability.logging.level", "event_processing.workers", "rate_limiting"}; !reflect.DeepEqual(status.Applied, want) {
+		t.Errorf("applied = %q, want %q", status.Applied, want)
+	}
+	if want := []string{"server.port", "kafka.brokers"}; !reflect.DeepEqual(status.RestartRequired, want) {
+		t.Errorf("restart required = %q, want %q", status.RestartRequired, want)
+	}
+
+	if len(subsystem.configs) != 1 {
+		t.Fatalf("subsystem notified %d times", len(subsystem.configs))
+	}
+	current := reloader.Current()
+	if current != subsystem.configs[0] {
+		t.Error("subsystem was not given the running configuration")
+	}
+	if current.Observability.Logging.Level != "debug" || current.EventProcessing.Workers != 8 || current.RateLimiting.BurstSize != 100 {
+		t.Errorf("live settings not applied: %+v", current)
+	}
+	if current.Server.Port != "8090" || current.Kafka.Brokers[0] != "localhost:9092" {
+		t.Errorf("immutable settings changed: port %s, brokers %v", current.Server.Port, current.Kafka.Brokers)
+	}
+	if running.Observability.Logging.Level != "info" {
+		t.Error("the previous configuration was modified")
+	}
+
+	if got, ok := reloader.Status(); !ok || !reflect.DeepEqual(got, status) {
+		t.Errorf("Status() = %+v, %v", got, ok)
+	}
+}
+
+func TestReloadWithoutLiveChanges(t *testing.T) {
+	running := validConfig()
+	loaded := validConfig()
+	loaded.Kafka.Brokers = []string{"kafka-3:9092"}
+
+	reloader := newReloader(running, func() (*Config, error) { return loaded, nil }, zap.NewNop())
+	subsystem := &recordingReloader{}
+	reloader.Register("subsystem", subsystem)
+
+	status := reloader.Reload(ReloadTriggerFile, "")
+	if !status.Success || len(status.Applied) != 0 || len(status.RestartRequired) != 1 {
+		t.Errorf("status = %+v", status)
+	}
+	if len(subsystem.configs) != 0 || reloader.Current() != running {
+		t.Error("nothing should have been applied")
+	}
+}
+
+func TestReloadRejectsInvalidConfig(t *testing.T) {
+	running := validConfig()
+	reloader := newReloader(running, func() (*Config, error) {
+		return nil, errors.New("kafka brokers are required")
+	}, zap.NewNop())
+	subsystem := &recordingReloader{}
+	reloader.Register("subsystem", subsystem)
+
+	if _, ok := reloader.Status(); ok {
+		t.Error("status reported before any reload")
+	}
+	status := reloader.Reload(ReloadTriggerAPI, "ops")
+	if status.Success || status.Error != "kafka brokers are required" || status.Actor != "ops" {
+		t.Errorf("status = %+v", status)
+	}
+	if len(subsystem.configs) != 0 || reloader.Current() != running {
+		t.Error("an invalid configuration was applied")
+	}
+}
+
+func TestReloadReportsSubsystemErrors(t *testing.T) {
+	loaded := validConfig()
+	loaded.Observability.Logging.Level = "warn"
+	reloader := newReloader(validConfig(), func() (*Config, error) { return loaded, nil }, zap.NewNop())
+	reloader.Register("logging", &recordingReloader{err: errors.New("unknown level")})
+	other := &recordingReloader{}
+	reloader.Register("processors", other)
+
+	status := reloader.Reload(ReloadTriggerAPI, "ops")
+	if status.Success || status.Error != "logging: unknown level" {
+		t.Errorf("status = %+v", status)
+	}
+	if len(other.configs) != 1 {
+		t.Error("a failing subsystem kept the others from being notified")
+	}
+}
+
+func TestWatchFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(file, []byte("environment: development\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes, err := watchFile(ctx, file, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Other files in the directory are ignored
+	if err := os.WriteFile(filepath.Join(dir, "other.yaml"), []byte("x: 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Several writes settle into one change
+	for i := 0; i < 3; i++ {
+		if err := os.WriteFile(file, []byte("environment: staging\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	select {
+	case <-changes:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change signalled")
+	}
+	select {
+	case <-changes:
+		t.Error("writes were signalled more than once")
+	case <-time.After(2 * fileSettleTime):
+	}
+}
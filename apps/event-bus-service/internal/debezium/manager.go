@@ -11,6 +11,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -27,6 +29,7 @@ type Manager struct {
 	logger     *zap.Logger
 	httpClient *http.Client
 	connectors map[string]*ConnectorStatus
+	secrets    SecretResolver
 	mutex      sync.RWMutex
 	metrics    *DebeziumMetrics
 	stopCh     chan struct{}
@@ -108,7 +111,8 @@ type PostgresConnectorConfig struct {
 	SMTTopicReplacement        string `json:"transforms.route.topic.replacement,omitempty"`
 }
 
-// NewManager creates a new Debezium manager instance
+// NewManager creates a new Debezium manager instance. It does not connect to
+// Debezium Connect; Ping tells when Connect is reachable.
 func NewManager(cfg *config.Config, logger *zap.Logger) (*Manager, error) {
 	if logger == nil {
 		logger = zap.NewNop()
@@ -117,20 +121,21 @@ func NewManager(cfg *config.Config, logger *zap.Logger) (*Manager, error) {
 	// Create HTTP client with timeouts and security configuration
 	httpClient := createHTTPClient(cfg)
 
+	secrets, err := NewSecretResolver(cfg.Debezium.Secrets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure connector secrets: %w", err)
+	}
+
 	manager := &Manager{
 		config:     cfg,
 		logger:     logger,
 		httpClient: httpClient,
 		connectors: make(map[string]*ConnectorStatus),
+		secrets:    secrets,
 		metrics:    initDebeziumMetrics(),
 		stopCh:     make(chan struct{}),
 	}
 
-	// Test connectivity to Debezium Connect
-	if err := manager.testConnectivity(); err != nil {
-		return nil, fmt.Errorf("failed to connect to Debezium Connect: %w", err)
-	}
-
 	logger.Info("Debezium manager initialized successfully",
 		zap.String("connect_url", cfg.Debezium.Connect.URL))
 
@@ -206,7 +211,10 @@ func (m *Manager) Stop() error {
 	return nil
 }
 
-// CreateConnector creates a new Debezium connector
+// CreateConnector creates a new Debezium connector. Its ${secret:key}
+// references are resolved only in the config sent to Kafka Connect; the
+// config kept and reported by the manager holds the references, and any
+// secret in plaintext is masked.
 func (m *Manager) CreateConnector(ctx context.Context, connectorConfig *ConnectorConfig) error {
 	start := time.Now()
 	defer func() {
@@ -218,8 +226,13 @@ func (m *Manager) CreateConnector(ctx context.Context, connectorConfig *Connecto
 		return fmt.Errorf("invalid connector configuration: %w", err)
 	}
 
+	resolved, secrets, err := resolveSecrets(ctx, m.secrets, connectorConfig.Config)
+	if err != nil {
+		return fmt.Errorf("invalid connector configuration: %w", err)
+	}
+
 	// Prepare request
-	jsonData, err := json.Marshal(connectorConfig)
+	jsonData, err := json.Marshal(&ConnectorConfig{Name: connectorConfig.Name, Config: resolved})
 	if err != nil {
 		return fmt.Errorf("failed to marshal connector config: %w", err)
 	}
@@ -248,7 +261,7 @@ func (m *Manager) CreateConnector(ctx context.Context, connectorConfig *Connecto
 
 	if resp.StatusCode != http.StatusCreated {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to create connector, status: %d, body: %s", resp.StatusCode, string(body))
+		return fmt.Errorf("failed to create connector, status: %d, body: %s", resp.StatusCode, redact(string(body), secrets))
 	}
 
 	m.logger.Info("Connector created successfully",
@@ -260,7 +273,7 @@ func (m *Manager) CreateConnector(ctx context.Context, connectorConfig *Connecto
 		Name:        connectorConfig.Name,
 		Type:        m.getConnectorType(connectorConfig.Config),
 		State:       "RUNNING",
-		Config:      convertStringMapToInterface(connectorConfig.Config),
+		Config:      convertStringMapToInterface(MaskConfig(connectorConfig.Config)),
 		LastUpdated: time.Now(),
 		HealthScore: 1.0,
 	}
@@ -394,9 +407,14 @@ func (m *Manager) GetConnectorStatus(ctx context.Context, connectorName string)
 
 	status.LastUpdated = time.Now()
 	status.HealthScore = m.calculateHealthScore(&status)
+	status.Config = maskStatusConfig(status.Config)
 
-	// Update local cache
+	// Update local cache, keeping the config the connector was created with
+	// as the status of Kafka Connect has none
 	m.mutex.Lock()
+	if previous, exists := m.connectors[connectorName]; exists && status.Config == nil {
+		status.Config = previous.Config
+	}
 	m.connectors[connectorName] = &status
 	m.mutex.Unlock()
 
@@ -437,6 +455,135 @@ func (m *Manager) ListConnectors(ctx context.Context) ([]string, error) {
 	return connectors, nil
 }
 
+// MigrateConnectorSecrets moves the plaintext secrets of an existing
+// connector to references: references maps config keys to the ${secret:key}
+// reference replacing their value. Every reference must resolve to the value
+// the connector is configured with, and every plaintext secret must be
+// replaced, so the migration neither breaks the connector nor leaves a
+// secret behind. The connector is then re-registered with Kafka Connect from
+// its references and its config returned, masked.
+func (m *Manager) MigrateConnectorSecrets(ctx context.Context, connectorName string, references map[string]string) (map[string]string, error) {
+	start := time.Now()
+	defer func() {
+		m.metrics.APIResponseTime.Observe(time.Since(start).Seconds())
+	}()
+
+	current, err := m.getConnectorConfig(ctx, connectorName)
+	if err != nil {
+		return nil, err
+	}
+
+	migrated := make(map[string]string, len(current))
+	for key, value := range current {
+		migrated[key] = value
+	}
+	for key, ref := range references {
+		if !IsSecretKey(key) {
+			return nil, fmt.Errorf("%w: %s does not hold a secret", ErrInvalidSecretMigration, key)
+		}
+		if !HasSecretRef(ref) {
+			return nil, fmt.Errorf("%w: %s must be set to a ${secret:key} reference", ErrInvalidSecretMigration, key)
+		}
+		if _, exists := current[key]; !exists {
+			return nil, fmt.Errorf("%w: connector %s has no %s", ErrInvalidSecretMigration, connectorName, key)
+		}
+		migrated[key] = ref
+	}
+	if left := PlaintextSecrets(migrated); len(left) > 0 {
+		sort.Strings(left)
+		return nil, fmt.Errorf("%w: connector %s still holds %s in plaintext", ErrInvalidSecretMigration, connectorName, strings.Join(left, ", "))
+	}
+
+	resolved, secrets, err := resolveSecrets(ctx, m.secrets, migrated)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSecretMigration, err)
+	}
+	for key := range references {
+		if resolved[key] != current[key] {
+			return nil, fmt.Errorf("%w: %s does not resolve to the value connector %s is configured with", ErrInvalidSecretMigration, key, connectorName)
+		}
+	}
+
+	jsonData, err := json.Marshal(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal connector config: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/connectors/%s/config", m.config.Debezium.Connect.URL, connectorName)
+	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	m.setAuthHeaders(req)
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update connector: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to update connector, status: %d, body: %s", resp.StatusCode, redact(string(body), secrets))
+	}
+
+	m.logger.Info("Connector secrets migrated to references",
+		zap.String("connector", connectorName),
+		zap.Int("secrets", len(references)))
+
+	masked := MaskConfig(migrated)
+	m.mutex.Lock()
+	if status, exists := m.connectors[connectorName]; exists {
+		status.Config = convertStringMapToInterface(masked)
+		status.LastUpdated = time.Now()
+	} else {
+		m.connectors[connectorName] = &ConnectorStatus{
+			Name:        connectorName,
+			Type:        m.getConnectorType(migrated),
+			Config:      convertStringMapToInterface(masked),
+			LastUpdated: time.Now(),
+		}
+	}
+	m.mutex.Unlock()
+
+	return masked, nil
+}
+
+// getConnectorConfig returns the config Kafka Connect holds for a connector,
+// secrets included; it must not be returned or logged unmasked
+func (m *Manager) getConnectorConfig(ctx context.Context, connectorName string) (map[string]string, error) {
+	url := fmt.Sprintf("%s/connectors/%s/config", m.config.Debezium.Connect.URL, connectorName)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	m.setAuthHeaders(req)
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connector config: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("connector %s not found", connectorName)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get connector config, status: %d", resp.StatusCode)
+	}
+
+	var config map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&config); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return config, nil
+}
+
 // CreatePostgreSQLConnector creates a PostgreSQL CDC connector with optimized configuration
 func (m *Manager) CreatePostgreSQLConnector(ctx context.Context, dbConfig config.DatabaseConfig, topicPrefix string) error {
 	connectorName := fmt.Sprintf("%s-postgres-connector", topicPrefix)
@@ -498,7 +645,7 @@ func (m *Manager) HealthCheck(ctx context.Context) error {
 	}()
 
 	// Test basic connectivity
-	if err := m.testConnectivity(); err != nil {
+	if err := m.Ping(ctx); err != nil {
 		return fmt.Errorf("connectivity check failed: %w", err)
 	}
 
@@ -534,9 +681,9 @@ func (m *Manager) HealthCheck(ctx context.Context) error {
 	return nil
 }
 
-// testConnectivity tests basic connectivity to Debezium Connect
-func (m *Manager) testConnectivity() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// Ping tests basic connectivity to Debezium Connect
+func (m *Manager) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	url := fmt.Sprintf("%s/", m.config.Debezium.Connect.URL)
@@ -567,6 +714,11 @@ func (m *Manager) initializeConnectors(ctx context.Context) error {
 			zap.String("name", connectorConfig.Name),
 			zap.String("type", connectorConfig.Type))
 
+		if password := connectorConfig.Database.Password; password != "" && !HasSecretRef(password) {
+			m.logger.Warn("Connector database password is configured in plaintext; set it to a ${secret:key} reference",
+				zap.String("name", connectorConfig.Name))
+		}
+
 		switch connectorConfig.Type {
 		case "postgres", "postgresql":
 			if err := m.CreatePostgreSQLConnector(ctx, connectorConfig.Database, connectorConfig.Topics.Prefix); err != nil {
@@ -0,0 +1,189 @@
+package debezium
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+)
+
+// maskedValue replaces the plaintext secrets of connector configs in
+// responses and logs
+const maskedValue = "********"
+
+// secretRefPattern matches the references to secrets connector configs hold
+// in place of their values, such as ${secret:postgres/password}
+var secretRefPattern = regexp.MustCompile(`\$\{secret:([A-Za-z0-9_.-]+(?:/[A-Za-z0-9_.-]+)*)\}`)
+
+// secretKeyMarkers are the parts of the config keys whose values are secrets
+var secretKeyMarkers = []string{"password", "secret", "token", "credential", "jaas.config", "api.key", "apikey", "private.key"}
+
+// ErrInvalidSecretMigration is returned when the references given to migrate
+// the secrets of a connector would break it or leave a secret in plaintext
+var ErrInvalidSecretMigration = errors.New("invalid secret migration")
+
+// SecretResolver resolves the keys of secret references to their values.
+// The providers of shared/secrets satisfy it.
+type SecretResolver interface {
+	GetSecret(ctx context.Context, key string) (string, error)
+}
+
+// NewSecretResolver creates the resolver configured by cfg
+func NewSecretResolver(cfg config.DebeziumSecretsConfig) (SecretResolver, error) {
+	switch cfg.Provider {
+	case "", "environment":
+		return &EnvironmentSecrets{Prefix: cfg.Prefix}, nil
+	case "file":
+		if cfg.Path == "" {
+			return nil, fmt.Errorf("secrets path is required by the file provider")
+		}
+		return &FileSecrets{Dir: cfg.Path}, nil
+	default:
+		return nil, fmt.Errorf("unsupported secrets provider %q", cfg.Provider)
+	}
+}
+
+// EnvironmentSecrets resolves secrets from environment variables: key
+// postgres/password is read from Prefix+POSTGRES_PASSWORD
+type EnvironmentSecrets struct {
+	Prefix string
+}
+
+// GetSecret returns the secret of key
+func (e *EnvironmentSecrets) GetSecret(ctx context.Context, key string) (string, error) {
+	name := e.Prefix + strings.ToUpper(strings.NewReplacer("/", "_", ".", "_", "-", "_").Replace(key))
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("secret %s not found in environment variable %s", key, name)
+	}
+	return value, nil
+}
+
+// FileSecrets resolves secrets from files, one per key under Dir as mounted
+// by Kubernetes secrets; the trailing newline of a file is dropped
+type FileSecrets struct {
+	Dir string
+}
+
+// GetSecret returns the secret of key
+func (f *FileSecrets) GetSecret(ctx context.Context, key string) (string, error) {
+	for _, part := range strings.Split(key, "/") {
+		if part == "." || part == ".." {
+			return "", fmt.Errorf("secret key %s must not leave the secrets directory", key)
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(f.Dir, filepath.FromSlash(key)))
+	if err != nil {
+		return "", fmt.Errorf("secret %s not found: %w", key, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// IsSecretKey reports whether the values of config key are secrets
+func IsSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, marker := range secretKeyMarkers {
+		if strings.Contains(key, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasSecretRef reports whether value refers to a secret
+func HasSecretRef(value string) bool {
+	return secretRefPattern.MatchString(value)
+}
+
+// PlaintextSecrets returns the keys of config holding secrets in plaintext
+// rather than as references
+func PlaintextSecrets(config map[string]string) []string {
+	var keys []string
+	for key, value := range config {
+		if IsSecretKey(key) && value != "" && !HasSecretRef(value) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// MaskConfig returns config with the values of its secret keys masked,
+// unless they refer to their secret rather than holding it
+func MaskConfig(config map[string]string) map[string]string {
+	if config == nil {
+		return nil
+	}
+	masked := make(map[string]string, len(config))
+	for key, value := range config {
+		if IsSecretKey(key) && value != "" && !HasSecretRef(value) {
+			value = maskedValue
+		}
+		masked[key] = value
+	}
+	return masked
+}
+
+// maskStatusConfig masks the secret values of the config of a connector
+// status, as Kafka Connect returns them
+func maskStatusConfig(config map[string]interface{}) map[string]interface{} {
+	for key, value := range config {
+		if s, ok := value.(string); ok && IsSecretKey(key) && s != "" && !HasSecretRef(s) {
+			config[key] = maskedValue
+		}
+	}
+	return config
+}
+
+// resolveSecrets returns config with its secret references replaced by their
+// values, along with every secret value it holds, resolved or in plaintext,
+// for redaction. Errors name the keys of the secrets, never their values.
+func resolveSecrets(ctx context.Context, resolver SecretResolver, config map[string]string) (map[string]string, []string, error) {
+	resolved := make(map[string]string, len(config))
+	var secrets []string
+	for key, value := range config {
+		if !HasSecretRef(value) {
+			if IsSecretKey(key) && value != "" {
+				secrets = append(secrets, value)
+			}
+			resolved[key] = value
+			continue
+		}
+		if resolver == nil {
+			return nil, nil, fmt.Errorf("%s refers to a secret but no secrets provider is configured", key)
+		}
+
+		var resolveErr error
+		resolved[key] = secretRefPattern.ReplaceAllStringFunc(value, func(ref string) string {
+			secretKey := secretRefPattern.FindStringSubmatch(ref)[1]
+			secret, err := resolver.GetSecret(ctx, secretKey)
+			if err != nil {
+				if resolveErr == nil {
+					resolveErr = fmt.Errorf("failed to resolve %s of %s: %w", ref, key, err)
+				}
+				return ref
+			}
+			if secret != "" {
+				secrets = append(secrets, secret)
+			}
+			return secret
+		})
+		if resolveErr != nil {
+			return nil, nil, resolveErr
+		}
+	}
+	return resolved, secrets, nil
+}
+
+// redact replaces the secrets in s, such as a Kafka Connect error echoing the
+// config it was sent
+func redact(s string, secrets []string) string {
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, maskedValue)
+	}
+	return s
+}
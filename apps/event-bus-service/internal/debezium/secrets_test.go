@@ -0,0 +1,232 @@
+package debezium
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+)
+
+const testSecret = "s3cr3t-pg-password"
+
+// testMetrics is shared by the managers of the tests, as the metrics are
+// registered globally
+var testMetrics = sync.OnceValue(initDebeziumMetrics)
+
+// fakeConnect is a Kafka Connect REST API recording the configs sent to it.
+// Creations are rejected with the config echoed, as Connect does for invalid
+// configs, when the connector is named "rejected".
+type fakeConnect struct {
+	mu      sync.Mutex
+	created map[string]string
+	updated map[string]string
+	// existing is the config of the connector "legacy"
+	existing map[string]string
+}
+
+func (f *fakeConnect) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	body, _ := io.ReadAll(r.Body)
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/connectors":
+		var req ConnectorConfig
+		json.Unmarshal(body, &req)
+		if req.Name == "rejected" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write(body)
+			return
+		}
+		f.created = req.Config
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodGet && r.URL.Path == "/connectors/legacy/config":
+		json.NewEncoder(w).Encode(f.existing)
+	case r.Method == http.MethodPut && r.URL.Path == "/connectors/legacy/config":
+		json.Unmarshal(body, &f.updated)
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/status"):
+		w.Write([]byte(`{"name":"orders","connector":{"state":"RUNNING"},"tasks":[]}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestManager(t *testing.T, connect http.Handler) (*Manager, *observer.ObservedLogs) {
+	t.Helper()
+	server := httptest.NewServer(connect)
+	t.Cleanup(server.Close)
+
+	core, logs := observer.New(zap.DebugLevel)
+	cfg := &config.Config{}
+	cfg.Debezium.Connect.URL = server.URL
+	return &Manager{
+		config:     cfg,
+		logger:     zap.New(core),
+		httpClient: server.Client(),
+		connectors: make(map[string]*ConnectorStatus),
+		secrets:    &EnvironmentSecrets{Prefix: "TEST_"},
+		metrics:    testMetrics(),
+		stopCh:     make(chan struct{}),
+	}, logs
+}
+
+// assertNoSecret fails when the secret appears in v, marshaled, or in logs
+func assertNoSecret(t *testing.T, what string, v interface{}, logs *observer.ObservedLogs) {
+	t.Helper()
+	data, _ := json.Marshal(v)
+	if strings.Contains(string(data), testSecret) {
+		t.Errorf("%s holds the secret: %s", what, data)
+	}
+	for _, entry := range logs.All() {
+		line, _ := json.Marshal(entry.ContextMap())
+		if strings.Contains(entry.Message, testSecret) || strings.Contains(string(line), testSecret) {
+			t.Errorf("log line holds the secret: %s %s", entry.Message, line)
+		}
+	}
+}
+
+func TestCreateConnectorResolvesSecretReferences(t *testing.T) {
+	t.Setenv("TEST_POSTGRES_PASSWORD", testSecret)
+	connect := &fakeConnect{}
+	manager, logs := newTestManager(t, connect)
+	ctx := context.Background()
+
+	err := manager.CreateConnector(ctx, &ConnectorConfig{Name: "orders", Config: map[string]string{
+		"connector.class":   "io.debezium.connector.postgresql.PostgresConnector",
+		"database.user":     "eventbus",
+		"database.password": "${secret:postgres/password}",
+	}})
+	if err != nil {
+		t.Fatalf("CreateConnector: %v", err)
+	}
+	if got := connect.created["database.password"]; got != testSecret {
+		t.Errorf("Connect got database.password %q, want the resolved secret", got)
+	}
+
+	status, err := manager.GetConnectorStatus(ctx, "orders")
+	if err != nil {
+		t.Fatalf("GetConnectorStatus: %v", err)
+	}
+	if got := status.Config["database.password"]; got != "${secret:postgres/password}" {
+		t.Errorf("stored database.password = %v, want the reference", got)
+	}
+	assertNoSecret(t, "status", status, logs)
+
+	// Connect echoing the config in an error does not leak the secret
+	err = manager.CreateConnector(ctx, &ConnectorConfig{Name: "rejected", Config: map[string]string{
+		"connector.class":   "io.debezium.connector.postgresql.PostgresConnector",
+		"database.password": "${secret:postgres/password}",
+	}})
+	if err == nil || !strings.Contains(err.Error(), maskedValue) {
+		t.Fatalf("CreateConnector rejected = %v, want the Connect error masked", err)
+	}
+	assertNoSecret(t, "error", err.Error(), logs)
+
+	// A missing secret is reported by key, and nothing is sent
+	connect.created = nil
+	err = manager.CreateConnector(ctx, &ConnectorConfig{Name: "orders-2", Config: map[string]string{
+		"connector.class":   "io.debezium.connector.postgresql.PostgresConnector",
+		"database.password": "${secret:postgres/missing}",
+	}})
+	if err == nil || !strings.Contains(err.Error(), "postgres/missing") || connect.created != nil {
+		t.Errorf("CreateConnector with a missing secret = %v, want an error naming it and no connector", err)
+	}
+}
+
+func TestMigrateConnectorSecrets(t *testing.T) {
+	t.Setenv("TEST_POSTGRES_PASSWORD", testSecret)
+	t.Setenv("TEST_POSTGRES_OTHER", "another-password")
+	connect := &fakeConnect{existing: map[string]string{
+		"connector.class":   "io.debezium.connector.postgresql.PostgresConnector",
+		"database.user":     "eventbus",
+		"database.password": testSecret,
+	}}
+	manager, logs := newTestManager(t, connect)
+	ctx := context.Background()
+
+	for name, references := range map[string]map[string]string{
+		"a reference resolving to another value": {"database.password": "${secret:postgres/other}"},
+		"a plaintext value":                      {"database.password": "new-password"},
+		"a key not holding a secret":             {"database.password": "${secret:postgres/password}", "database.user": "${secret:postgres/password}"},
+		"a plaintext secret left":                {},
+	} {
+		_, err := manager.MigrateConnectorSecrets(ctx, "legacy", references)
+		if !errors.Is(err, ErrInvalidSecretMigration) {
+			t.Errorf("%s: err = %v, want ErrInvalidSecretMigration", name, err)
+		}
+		if err != nil {
+			assertNoSecret(t, name, err.Error(), logs)
+		}
+	}
+	if connect.updated != nil {
+		t.Fatal("an invalid migration updated the connector")
+	}
+
+	config, err := manager.MigrateConnectorSecrets(ctx, "legacy", map[string]string{"database.password": "${secret:postgres/password}"})
+	if err != nil {
+		t.Fatalf("MigrateConnectorSecrets: %v", err)
+	}
+	if config["database.password"] != "${secret:postgres/password}" || connect.updated["database.password"] != testSecret {
+		t.Errorf("config = %v, Connect got %v; want the reference kept and the secret sent", config, connect.updated)
+	}
+	status, _ := manager.GetConnectorStatus(ctx, "legacy")
+	if got := status.Config["database.password"]; got != "${secret:postgres/password}" {
+		t.Errorf("stored database.password = %v, want the reference", got)
+	}
+	assertNoSecret(t, "migrated config", config, logs)
+}
+
+func TestMaskConfig(t *testing.T) {
+	masked := MaskConfig(map[string]string{
+		"database.password":       testSecret,
+		"database.sslpassword":    "${secret:postgres/ssl}",
+		"sasl.jaas.config":        `org.apache.kafka.common.security.plain.PlainLoginModule required password="x";`,
+		"key.converter":           "org.apache.kafka.connect.json.JsonConverter",
+		"database.user":           "eventbus",
+		"schema.history.token":    "",
+		"transforms.route.regex":  "([^.]+)",
+		"connection.secret.value": "abc",
+	})
+	want := map[string]string{
+		"database.password":       maskedValue,
+		"database.sslpassword":    "${secret:postgres/ssl}",
+		"sasl.jaas.config":        maskedValue,
+		"key.converter":           "org.apache.kafka.connect.json.JsonConverter",
+		"database.user":           "eventbus",
+		"schema.history.token":    "",
+		"transforms.route.regex":  "([^.]+)",
+		"connection.secret.value": maskedValue,
+	}
+	for key, value := range want {
+		if masked[key] != value {
+			t.Errorf("%s = %q, want %q", key, masked[key], value)
+		}
+	}
+}
+
+func TestFileSecrets(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "postgres"), 0o700)
+	os.WriteFile(filepath.Join(dir, "postgres", "password"), []byte(testSecret+"\n"), 0o600)
+	secrets := &FileSecrets{Dir: dir}
+
+	if got, err := secrets.GetSecret(context.Background(), "postgres/password"); err != nil || got != testSecret {
+		t.Errorf("GetSecret = %q, %v; want the file without its newline", got, err)
+	}
+	if _, err := secrets.GetSecret(context.Background(), "../postgres/password"); err == nil {
+		t.Error("GetSecret outside the directory succeeded, want an error")
+	}
+}
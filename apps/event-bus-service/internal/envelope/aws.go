@@ -0,0 +1,194 @@
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// AWSConfig holds the settings of an AWS KMS key
+type AWSConfig struct {
+	Region string
+	// KeyID is the ID, ARN or alias of the key encrypting new data keys
+	KeyID           string
+	Endpoint        string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// AWSKMS generates and decrypts data keys with AWS KMS, calling its JSON API
+// signed with SigV4, so no SDK is required. KMS rotates the key material of
+// a key itself, and decrypts the data keys of every version.
+type AWSKMS struct {
+	endpoint *url.URL
+	config   AWSConfig
+	client   *http.Client
+	now      func() time.Time
+}
+
+// NewAWSKMS creates a KMS of the key of cfg
+func NewAWSKMS(cfg AWSConfig) (*AWSKMS, error) {
+	if cfg.KeyID == "" {
+		return nil, fmt.Errorf("kms key id is required")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("kms credentials are required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = fmt.Sprintf("https://kms.%s.amazonaws.com", cfg.Region)
+	}
+
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kms endpoint: %w", err)
+	}
+	return &AWSKMS{endpoint: u, config: cfg, client: &http.Client{Timeout: 10 * time.Second}, now: time.Now}, nil
+}
+
+// GenerateDataKey returns a new AES-256 data key encrypted by the key of the
+// KMS
+func (k *AWSKMS) GenerateDataKey(ctx context.Context) (*DataKey, error) {
+	var response struct {
+		KeyID          string `json:"KeyId"`
+		Plaintext      []byte `json:"Plaintext"`
+		CiphertextBlob []byte `json:"CiphertextBlob"`
+	}
+	err := k.call(ctx, "GenerateDataKey", map[string]string{"KeyId": k.config.KeyID, "KeySpec": "AES_256"}, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &DataKey{KeyID: response.KeyID, Plaintext: response.Plaintext, Encrypted: response.CiphertextBlob}, nil
+}
+
+// Decrypt returns the plaintext of a data key encrypted by the key keyID
+func (k *AWSKMS) Decrypt(ctx context.Context, keyID string, encrypted []byte) ([]byte, error) {
+	var response struct {
+		Plaintext []byte `json:"Plaintext"`
+	}
+	request := map[string]interface{}{"KeyId": keyID, "CiphertextBlob": encrypted}
+	if err := k.call(ctx, "Decrypt", request, &response); err != nil {
+		return nil, err
+	}
+	return response.Plaintext, nil
+}
+
+// call sends a request of the KMS API and decodes its response into out
+func (k *AWSKMS) call(ctx context.Context, action string, request, out interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, k.endpoint.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService."+action)
+	k.sign(req, body, k.now())
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("kms %s request failed: %w", action, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read kms %s response: %w", action, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		json.Unmarshal(data, &failure)
+		if strings.HasSuffix(failure.Type, "NotFoundException") || strings.HasSuffix(failure.Type, "IncorrectKeyException") {
+			return fmt.Errorf("%w: %s", ErrKeyNotFound, failure.Message)
+		}
+		if strings.HasSuffix(failure.Type, "InvalidCiphertextException") {
+			return ErrInvalidCiphertext
+		}
+		return fmt.Errorf("kms %s failed with status %d: %s %s", action, resp.StatusCode, failure.Type, failure.Message)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode kms %s response: %w", action, err)
+	}
+	return nil
+}
+
+// sign adds the SigV4 Authorization header of a request with body
+func (k *AWSKMS) sign(req *http.Request, body []byte, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	shortDate := now.Format("20060102")
+	scope := fmt.Sprintf("%s/%s/kms/aws4_request", shortDate, k.config.Region)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if k.config.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", k.config.SessionToken)
+	}
+	headerNames := []string{"content-type", "host", "x-amz-date", "x-amz-target"}
+	if k.config.SessionToken != "" {
+		headerNames = append(headerNames, "x-amz-security-token")
+	}
+
+	var canonicalHeaders strings.Builder
+	for _, name := range headerNames {
+		value := req.Header.Get(name)
+		if name == "host" {
+			value = req.URL.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	signedHeaders := strings.Join(headerNames, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	payloadHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		"",
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+k.config.SecretAccessKey), shortDate)
+	signingKey = hmacSHA256(signingKey, k.config.Region)
+	signingKey = hmacSHA256(signingKey, "kms")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		k.config.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
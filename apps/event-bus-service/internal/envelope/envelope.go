@@ -0,0 +1,211 @@
+// Package envelope encrypts the data of messages with envelope encryption:
+// each payload is encrypted with AES-256-GCM under a data key, and the data
+// key, encrypted by a key management service, travels in the headers of the
+// message. Data keys are generated for a while then replaced; the KMS keeps
+// the keys that encrypted them, so older messages stay readable after a
+// rotation.
+package envelope
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Headers of an encrypted message
+const (
+	// AlgorithmHeader marks an encrypted message with the algorithm of its
+	// data
+	AlgorithmHeader = "encryption-algorithm"
+	// KeyIDHeader is the ID of the KMS key that encrypted the data key
+	KeyIDHeader = "encryption-key-id"
+	// DataKeyHeader is the encrypted data key, base64-encoded
+	DataKeyHeader = "encryption-data-key"
+)
+
+// Algorithm is the algorithm data is encrypted with
+const Algorithm = "AES-256-GCM"
+
+// maxOpenedKeys bounds the decrypted data keys kept to open messages
+const maxOpenedKeys = 1024
+
+var (
+	// ErrKeyNotFound is returned for a data key encrypted by a KMS key the
+	// KMS does not hold
+	ErrKeyNotFound = errors.New("encryption key not found")
+	// ErrUnsupportedAlgorithm is returned for a message encrypted with an
+	// algorithm other than Algorithm
+	ErrUnsupportedAlgorithm = errors.New("unsupported encryption algorithm")
+	// ErrInvalidCiphertext is returned when data does not decrypt: it was
+	// altered, or belongs to another message
+	ErrInvalidCiphertext = errors.New("invalid ciphertext")
+)
+
+// DataKey is a key encrypting message data, in plaintext and encrypted by
+// the KMS key KeyID
+type DataKey struct {
+	KeyID     string
+	Plaintext []byte
+	Encrypted []byte
+}
+
+// KMS generates data keys and decrypts them
+type KMS interface {
+	// GenerateDataKey returns a new 256-bit data key
+	GenerateDataKey(ctx context.Context) (*DataKey, error)
+	// Decrypt returns the plaintext of a data key encrypted by the KMS key
+	// keyID, or ErrKeyNotFound
+	Decrypt(ctx context.Context, keyID string, encrypted []byte) ([]byte, error)
+}
+
+// sealingKey is the data key messages are encrypted with
+type sealingKey struct {
+	aead      cipher.AEAD
+	headers   map[string]string
+	expiresAt time.Time
+}
+
+// Envelope encrypts and decrypts message data with the data keys of a KMS
+type Envelope struct {
+	kms KMS
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	current *sealingKey
+	// opened are the decrypted data keys, by KMS key and encrypted data key
+	opened map[string]cipher.AEAD
+}
+
+// New creates an envelope generating a data key with kms every ttl
+func New(kms KMS, ttl time.Duration) *Envelope {
+	return &Envelope{kms: kms, ttl: ttl, now: time.Now, opened: make(map[string]cipher.AEAD)}
+}
+
+// Encrypted reports whether the headers of a message mark it encrypted
+func Encrypted(headers map[string]string) bool {
+	_, ok := headers[AlgorithmHeader]
+	return ok
+}
+
+// Seal encrypts plaintext bound to aad, such as the ID of the message, and
+// returns the headers the message must carry to be opened
+func (e *Envelope) Seal(ctx context.Context, plaintext, aad []byte) ([]byte, map[string]string, error) {
+	key, err := e.sealingKey(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nonce := make([]byte, key.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+	headers := make(map[string]string, len(key.headers))
+	for k, v := range key.headers {
+		headers[k] = v
+	}
+	return key.aead.Seal(nonce, nonce, plaintext, aad), headers, nil
+}
+
+// sealingKey returns the current data key, generating the next one once it
+// expired
+func (e *Envelope) sealingKey(ctx context.Context) (*sealingKey, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.current != nil && e.now().Before(e.current.expiresAt) {
+		return e.current, nil
+	}
+
+	dataKey, err := e.kms.GenerateDataKey(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	aead, err := newAEAD(dataKey.Plaintext)
+	clear(dataKey.Plaintext)
+	if err != nil {
+		return nil, err
+	}
+	e.current = &sealingKey{
+		aead: aead,
+		headers: map[string]string{
+			AlgorithmHeader: Algorithm,
+			KeyIDHeader:     dataKey.KeyID,
+			DataKeyHeader:   base64.StdEncoding.EncodeToString(dataKey.Encrypted),
+		},
+		expiresAt: e.now().Add(e.ttl),
+	}
+	return e.current, nil
+}
+
+// Open decrypts the ciphertext of a message sealed with aad, given its
+// headers
+func (e *Envelope) Open(ctx context.Context, headers map[string]string, ciphertext, aad []byte) ([]byte, error) {
+	if algorithm := headers[AlgorithmHeader]; algorithm != Algorithm {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, algorithm)
+	}
+	aead, err := e.openingKey(ctx, headers[KeyIDHeader], headers[DataKeyHeader])
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, ErrInvalidCiphertext
+	}
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, aad)
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+	return plaintext, nil
+}
+
+// openingKey returns the data key of a message, decrypted by the KMS the
+// first time it is seen
+func (e *Envelope) openingKey(ctx context.Context, keyID, encodedDataKey string) (cipher.AEAD, error) {
+	cacheKey := keyID + "/" + encodedDataKey
+	e.mu.Lock()
+	aead, ok := e.opened[cacheKey]
+	e.mu.Unlock()
+	if ok {
+		return aead, nil
+	}
+
+	encrypted, err := base64.StdEncoding.DecodeString(encodedDataKey)
+	if err != nil || len(encrypted) == 0 {
+		return nil, fmt.Errorf("%w: malformed data key", ErrInvalidCiphertext)
+	}
+	plaintext, err := e.kms.Decrypt(ctx, keyID, encrypted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data key: %w", err)
+	}
+	aead, err = newAEAD(plaintext)
+	clear(plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	if len(e.opened) >= maxOpenedKeys {
+		clear(e.opened)
+	}
+	e.opened[cacheKey] = aead
+	e.mu.Unlock()
+	return aead, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("data key is %d bytes, want 32", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
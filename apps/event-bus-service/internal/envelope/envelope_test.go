@@ -0,0 +1,229 @@
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func randomKey(t *testing.T) []byte {
+	t.Helper()
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// countingKMS counts the calls to a KMS
+type countingKMS struct {
+	KMS
+	generated, decrypted atomic.Int32
+}
+
+func (k *countingKMS) GenerateDataKey(ctx context.Context) (*DataKey, error) {
+	k.generated.Add(1)
+	return k.KMS.GenerateDataKey(ctx)
+}
+
+func (k *countingKMS) Decrypt(ctx context.Context, keyID string, encrypted []byte) ([]byte, error) {
+	k.decrypted.Add(1)
+	return k.KMS.Decrypt(ctx, keyID, encrypted)
+}
+
+func TestEnvelopeRotation(t *testing.T) {
+	ctx := context.Background()
+	k1, k2 := randomKey(t), randomKey(t)
+	local, err := NewLocalKMS("k1", map[string][]byte{"k1": k1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kms := &countingKMS{KMS: local}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	producer := New(kms, time.Hour)
+	producer.now = func() time.Time { return now }
+
+	first, firstHeaders, err := producer.Seal(ctx, []byte(`{"answer":"secret"}`), []byte("event_1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(first, []byte("secret")) {
+		t.Fatal("ciphertext holds the plaintext")
+	}
+	if firstHeaders[AlgorithmHeader] != Algorithm || firstHeaders[KeyIDHeader] != "k1" || firstHeaders[DataKeyHeader] == "" {
+		t.Fatalf("headers = %v, want the algorithm, k1 and the data key", firstHeaders)
+	}
+	_, sameKey, _ := producer.Seal(ctx, []byte(`{}`), []byte("event_2"))
+	if sameKey[DataKeyHeader] != firstHeaders[DataKeyHeader] || kms.generated.Load() != 1 {
+		t.Errorf("a data key was generated for every message, want it reused within its TTL")
+	}
+
+	// The data key is replaced once its TTL elapsed
+	now = now.Add(time.Hour)
+	second, secondHeaders, err := producer.Seal(ctx, []byte(`{"answer":"other"}`), []byte("event_3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secondHeaders[DataKeyHeader] == firstHeaders[DataKeyHeader] || kms.generated.Load() != 2 {
+		t.Errorf("data key not rotated after its TTL")
+	}
+
+	// k2 becomes active; k1 stays to decrypt the messages it encrypted
+	rotated, err := NewLocalKMS("k2", map[string][]byte{"k1": k1, "k2": k2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	consumer := New(rotated, time.Hour)
+	for _, tt := range []struct {
+		ciphertext []byte
+		headers    map[string]string
+		aad, want  string
+	}{
+		{first, firstHeaders, "event_1", `{"answer":"secret"}`},
+		{second, secondHeaders, "event_3", `{"answer":"other"}`},
+	} {
+		plaintext, err := consumer.Open(ctx, tt.headers, tt.ciphertext, []byte(tt.aad))
+		if err != nil || string(plaintext) != tt.want {
+			t.Errorf("Open(%s) = %q, %v; want %s", tt.aad, plaintext, err, tt.want)
+		}
+	}
+	_, headers, err := New(rotated, time.Hour).Seal(ctx, []byte(`{}`), []byte("event_4"))
+	if err != nil || headers[KeyIDHeader] != "k2" {
+		t.Errorf("key ID after the rotation = %q, %v; want k2", headers[KeyIDHeader], err)
+	}
+
+	// Data keys are decrypted once
+	opener := New(kms, time.Hour)
+	for i := 0; i < 3; i++ {
+		if _, err := opener.Open(ctx, firstHeaders, first, []byte("event_1")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := kms.decrypted.Load(); got != 1 {
+		t.Errorf("data key decrypted %d times, want 1", got)
+	}
+
+	// A payload moved to another message does not open
+	if _, err := consumer.Open(ctx, firstHeaders, first, []byte("event_9")); !errors.Is(err, ErrInvalidCiphertext) {
+		t.Errorf("Open with another message ID: err = %v, want ErrInvalidCiphertext", err)
+	}
+	// Once k1 is dropped its messages no longer decrypt
+	dropped, _ := NewLocalKMS("k2", map[string][]byte{"k2": k2})
+	if _, err := New(dropped, time.Hour).Open(ctx, firstHeaders, first, []byte("event_1")); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Open without k1: err = %v, want ErrKeyNotFound", err)
+	}
+	unsupported := map[string]string{AlgorithmHeader: "ROT13", KeyIDHeader: "k1", DataKeyHeader: firstHeaders[DataKeyHeader]}
+	if _, err := consumer.Open(ctx, unsupported, first, []byte("event_1")); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Errorf("Open ROT13: err = %v, want ErrUnsupportedAlgorithm", err)
+	}
+}
+
+type staticSecrets map[string]string
+
+func (s staticSecrets) GetSecret(_ context.Context, key string) (string, error) {
+	if value, ok := s[key]; ok {
+		return value, nil
+	}
+	return "", errors.New("not found")
+}
+
+func TestLoadLocalKMS(t *testing.T) {
+	secrets := staticSecrets{"event-encryption/k1": "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8=\n"}
+	kms, err := LoadLocalKMS(context.Background(), secrets, "event-encryption", "k1", []string{"k1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key, err := kms.GenerateDataKey(context.Background()); err != nil || key.KeyID != "k1" {
+		t.Errorf("GenerateDataKey = %+v, %v; want a key of k1", key, err)
+	}
+
+	if _, err := LoadLocalKMS(context.Background(), secrets, "event-encryption", "k2", []string{"k1", "k2"}); err == nil {
+		t.Error("loaded a missing key")
+	}
+	short := staticSecrets{"event-encryption/k1": "c2hvcnQ="}
+	if _, err := LoadLocalKMS(context.Background(), short, "event-encryption", "k1", []string{"k1"}); err == nil {
+		t.Error("loaded a key of 5 bytes")
+	}
+}
+
+// fakeAWSKMS serves GenerateDataKey and Decrypt of the KMS API with a local
+// KMS, checking the requests are signed
+func fakeAWSKMS(t *testing.T, local *LocalKMS) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") ||
+			!strings.Contains(auth, "/eu-west-1/kms/aws4_request") ||
+			!strings.Contains(auth, "SignedHeaders=content-type;host;x-amz-date;x-amz-target,") {
+			t.Errorf("Authorization = %q", auth)
+		}
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+
+		switch r.Header.Get("X-Amz-Target") {
+		case "TrentService.GenerateDataKey":
+			var request struct{ KeyId, KeySpec string }
+			json.NewDecoder(r.Body).Decode(&request)
+			if request.KeyId != "alias/events" || request.KeySpec != "AES_256" {
+				t.Errorf("GenerateDataKey request = %+v", request)
+			}
+			key, _ := local.GenerateDataKey(r.Context())
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"KeyId": "arn:aws:kms:eu-west-1:123456789012:key/" + key.KeyID, "Plaintext": key.Plaintext, "CiphertextBlob": key.Encrypted,
+			})
+		case "TrentService.Decrypt":
+			var request struct {
+				KeyId          string
+				CiphertextBlob []byte
+			}
+			json.NewDecoder(r.Body).Decode(&request)
+			plaintext, err := local.Decrypt(r.Context(), strings.TrimPrefix(request.KeyId, "arn:aws:kms:eu-west-1:123456789012:key/"), request.CiphertextBlob)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"__type": "IncorrectKeyException", "message": err.Error()})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"Plaintext": plaintext})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+}
+
+func TestAWSKMS(t *testing.T) {
+	local, err := NewLocalKMS("k1", map[string][]byte{"k1": randomKey(t)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := fakeAWSKMS(t, local)
+	defer server.Close()
+
+	kms, err := NewAWSKMS(AWSConfig{Region: "eu-west-1", KeyID: "alias/events", Endpoint: server.URL, AccessKeyID: "AKID", SecretAccessKey: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := New(kms, time.Hour)
+	ctx := context.Background()
+	ciphertext, headers, err := e.Seal(ctx, []byte(`{"answer":"secret"}`), []byte("event_1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(headers[KeyIDHeader], "arn:aws:kms:") {
+		t.Errorf("key ID = %q, want the ARN KMS returned", headers[KeyIDHeader])
+	}
+	plaintext, err := New(kms, time.Hour).Open(ctx, headers, ciphertext, []byte("event_1"))
+	if err != nil || string(plaintext) != `{"answer":"secret"}` {
+		t.Errorf("Open = %q, %v", plaintext, err)
+	}
+
+	headers[KeyIDHeader] = "arn:aws:kms:eu-west-1:123456789012:key/k0"
+	if _, err := New(kms, time.Hour).Open(ctx, headers, ciphertext, []byte("event_1")); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Open with an unknown key: err = %v, want ErrKeyNotFound", err)
+	}
+}
@@ -0,0 +1,94 @@
+package envelope
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// SecretResolver resolves the keys of secrets to their values. The
+// providers of the Debezium connector secrets satisfy it.
+type SecretResolver interface {
+	GetSecret(ctx context.Context, key string) (string, error)
+}
+
+// LocalKMS wraps data keys with AES-256 keys held by the service, for
+// development. The active key encrypts new data keys; the others only
+// decrypt the data keys of older messages.
+type LocalKMS struct {
+	active string
+	keys   map[string]cipher.AEAD
+}
+
+// NewLocalKMS creates a KMS of 32-byte keys by ID, encrypting with the key
+// active
+func NewLocalKMS(active string, keys map[string][]byte) (*LocalKMS, error) {
+	if _, ok := keys[active]; !ok {
+		return nil, fmt.Errorf("active key %q is not among the keys", active)
+	}
+	kms := &LocalKMS{active: active, keys: make(map[string]cipher.AEAD, len(keys))}
+	for id, key := range keys {
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", id, err)
+		}
+		kms.keys[id] = aead
+	}
+	return kms, nil
+}
+
+// LoadLocalKMS creates a local KMS of the keys ids, read as base64 from the
+// secrets prefix/<id> of resolver
+func LoadLocalKMS(ctx context.Context, resolver SecretResolver, prefix, active string, ids []string) (*LocalKMS, error) {
+	keys := make(map[string][]byte, len(ids))
+	for _, id := range ids {
+		secret, err := resolver.GetSecret(ctx, strings.TrimSuffix(prefix, "/")+"/"+id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read encryption key %s: %w", id, err)
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(secret))
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %s is not base64", id)
+		}
+		keys[id] = key
+	}
+	return NewLocalKMS(active, keys)
+}
+
+// GenerateDataKey returns a random data key encrypted by the active key
+func (k *LocalKMS) GenerateDataKey(context.Context) (*DataKey, error) {
+	plaintext := make([]byte, 32)
+	if _, err := rand.Read(plaintext); err != nil {
+		return nil, err
+	}
+	aead := k.keys[k.active]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return &DataKey{
+		KeyID:     k.active,
+		Plaintext: plaintext,
+		Encrypted: aead.Seal(nonce, nonce, plaintext, []byte(k.active)),
+	}, nil
+}
+
+// Decrypt returns the plaintext of a data key encrypted by the key keyID
+func (k *LocalKMS) Decrypt(_ context.Context, keyID string, encrypted []byte) ([]byte, error) {
+	aead, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, keyID)
+	}
+	if len(encrypted) < aead.NonceSize() {
+		return nil, ErrInvalidCiphertext
+	}
+	nonce, sealed := encrypted[:aead.NonceSize()], encrypted[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, []byte(keyID))
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,122 @@
+package eventstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// rows is the part of *sql.Rows a cursor reads
+type rows interface {
+	Next() bool
+	Scan(dest ...interface{}) error
+	Err() error
+	Close() error
+}
+
+// Cursor reads the events of a search one row at a time, so a page is never
+// held in memory as a whole
+type Cursor struct {
+	rows   rows
+	filter Filter
+	event  *Event
+	err    error
+}
+
+// Next reads the next event and reports whether there was one
+func (c *Cursor) Next() bool {
+	if c.err != nil || !c.rows.Next() {
+		return false
+	}
+
+	var (
+		event   Event
+		payload []byte
+		headers []byte
+	)
+	if err := c.rows.Scan(&event.ID, &event.Topic, &event.EventType, &event.Source, &event.Subject,
+		&event.CorrelationID, &payload, &headers, &event.Timestamp); err != nil {
+		c.err = fmt.Errorf("failed to read event: %w", err)
+		return false
+	}
+	event.Payload = payload
+	if len(headers) > 0 {
+		if err := json.Unmarshal(headers, &event.Headers); err != nil {
+			c.err = fmt.Errorf("failed to decode headers of event %s: %w", event.ID, err)
+			return false
+		}
+	}
+
+	c.event = &event
+	return true
+}
+
+// Event returns the event read by the last call to Next
+func (c *Cursor) Event() *Event {
+	return c.event
+}
+
+// Err returns the error that stopped Next, if any
+func (c *Cursor) Err() error {
+	if c.err != nil {
+		return c.err
+	}
+	return c.rows.Err()
+}
+
+// Close releases the rows of the cursor
+func (c *Cursor) Close() error {
+	return c.rows.Close()
+}
+
+// Filter returns the filter of the search, with its limit applied
+func (c *Cursor) Filter() Filter {
+	return c.filter
+}
+
+// Page is the document written by WritePage. Events are the projections of
+// the stored events selected by include_fields and exclude_fields.
+type Page struct {
+	Events     []map[string]interface{} `json:"events"`
+	Count      int                      `json:"count"`
+	Limit      int                      `json:"limit"`
+	Offset     int                      `json:"offset"`
+	NextOffset *int                     `json:"next_offset"`
+}
+
+// WritePage streams the page to w as
+//
+//	{"events":[...],"count":n,"limit":l,"offset":o,"next_offset":x}
+//
+// encoding every event as it is read. next_offset is null on the last page.
+// An error while streaming leaves w with a truncated document.
+func (c *Cursor) WritePage(w io.Writer) (int, error) {
+	if _, err := io.WriteString(w, `{"events":[`); err != nil {
+		return 0, err
+	}
+
+	encoder := json.NewEncoder(w)
+	count := 0
+	for c.Next() {
+		if count > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return count, err
+			}
+		}
+		if err := encoder.Encode(c.filter.project(c.event)); err != nil {
+			return count, fmt.Errorf("failed to encode event %s: %w", c.event.ID, err)
+		}
+		count++
+	}
+	if err := c.Err(); err != nil {
+		return count, err
+	}
+
+	nextOffset := "null"
+	if count == c.filter.Limit {
+		nextOffset = fmt.Sprint(c.filter.Offset + count)
+	}
+	_, err := fmt.Fprintf(w, `],"count":%d,"limit":%d,"offset":%d,"next_offset":%s}`,
+		count, c.filter.Limit, c.filter.Offset, nextOffset)
+	return count, err
+}
@@ -0,0 +1,156 @@
+// Package eventstore keeps a queryable copy of the events consumed off the
+// event bus. A Sink writes events into the event_store table of the event
+// store database, Search filters them for POST /events/filter, and
+// RunRetention purges events older than the configured retention.
+package eventstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/projections"
+)
+
+// sinkName labels the metrics of the event store sink
+const sinkName = "event_store"
+
+// Event is one stored event. Payload is the JSON encoded data of the event.
+type Event struct {
+	ID            string
+	Topic         string
+	EventType     string
+	Source        string
+	Subject       string
+	CorrelationID string
+	Payload       json.RawMessage
+	Headers       map[string]string
+	Timestamp     time.Time
+}
+
+// EventFromMessage converts a consumed message into a stored event
+func EventFromMessage(message *kafka.Message) (*Event, error) {
+	if message.ID == "" {
+		return nil, fmt.Errorf("event id is required")
+	}
+
+	var payload []byte
+	switch data := message.Data.(type) {
+	case []byte:
+		payload = data
+	case json.RawMessage:
+		payload = data
+	default:
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode event data: %w", err)
+		}
+		payload = encoded
+	}
+	if !json.Valid(payload) {
+		// Opaque payloads are kept as a JSON string
+		encoded, err := json.Marshal(string(payload))
+		if err != nil {
+			return nil, err
+		}
+		payload = encoded
+	}
+
+	timestamp := message.Metadata.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	return &Event{
+		ID:            message.ID,
+		Topic:         message.Topic,
+		EventType:     message.EventType,
+		Source:        message.Source,
+		Subject:       message.Subject,
+		CorrelationID: message.CorrelationID,
+		Payload:       payload,
+		Headers:       message.Headers,
+		Timestamp:     timestamp.UTC(),
+	}, nil
+}
+
+// Sink consumes events and writes them into the event store. It implements
+// kafka.BatchConsumerHandler.
+type Sink struct {
+	store   Store
+	metrics *projections.Metrics
+	logger  *zap.Logger
+	topics  []string
+	groupID string
+}
+
+// NewSink creates the sink storing the events of cfg.Topics in store
+func NewSink(cfg config.EventStoreSinkConfig, store Store, metrics *projections.Metrics, logger *zap.Logger) *Sink {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	return &Sink{
+		store:   store,
+		metrics: metrics,
+		logger:  logger,
+		topics:  cfg.Topics,
+		groupID: cfg.GroupID,
+	}
+}
+
+// GetTopics returns the topics the sink consumes
+func (s *Sink) GetTopics() []string {
+	return s.topics
+}
+
+// GetGroupID returns the consumer group the sink commits offsets in
+func (s *Sink) GetGroupID() string {
+	return s.groupID
+}
+
+// HandleBatch stores a batch of events in a single write. Events without an
+// ID are logged and dropped; a failed write fails the whole batch so it is
+// redelivered, and redelivered events are skipped by their ID.
+func (s *Sink) HandleBatch(ctx context.Context, messages []*kafka.Message) error {
+	start := time.Now()
+
+	var newest time.Time
+	events := make([]Event, 0, len(messages))
+	for _, message := range messages {
+		event, err := EventFromMessage(message)
+		if err != nil {
+			s.logger.Warn("Dropping unstorable event",
+				zap.String("topic", message.Topic),
+				zap.String("event_type", message.EventType),
+				zap.Error(err))
+			s.metrics.Events.WithLabelValues(sinkName, "invalid").Inc()
+			continue
+		}
+		events = append(events, *event)
+		if event.Timestamp.After(newest) {
+			newest = event.Timestamp
+		}
+	}
+
+	inserted, err := s.store.InsertEvents(ctx, events)
+	if err != nil {
+		s.metrics.Events.WithLabelValues(sinkName, "failed").Add(float64(len(events)))
+		return fmt.Errorf("failed to write events to the event store: %w", err)
+	}
+
+	s.metrics.Events.WithLabelValues(sinkName, "projected").Add(float64(len(events)))
+	s.metrics.RowsWritten.WithLabelValues(sinkName).Add(float64(inserted))
+	s.metrics.DuplicateRows.WithLabelValues(sinkName).Add(float64(int64(len(events)) - inserted))
+	s.metrics.BatchDuration.WithLabelValues(sinkName).Observe(time.Since(start).Seconds())
+	if !newest.IsZero() {
+		s.metrics.Lag.WithLabelValues(sinkName).Set(time.Since(newest).Seconds())
+	}
+
+	return nil
+}
@@ -0,0 +1,379 @@
+package eventstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/events"
+)
+
+// ErrInvalidFilter is returned for filters that can't be turned into a query
+var ErrInvalidFilter = errors.New("invalid event filter")
+
+// Page bounds of a search. Limit is capped rather than rejected; an offset
+// past MaxOffset is rejected, as deep pages should be narrowed with a time
+// range instead.
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+	MaxOffset    = 100000
+)
+
+// Filter selects a page of stored events. It has the JSON shape of the
+// filter request of POST /events/filter.
+//
+// Condition fields are either top-level fields of an event (id, topic,
+// event_type, source, subject, correlation_id, timestamp), a header as
+// headers.<name>, or a path into the event data as data.<key>.<key>...
+type Filter struct {
+	events.EventFilter
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// columnFields maps the top-level fields of an event to their column
+var columnFields = map[string]string{
+	"id":             "event_id",
+	"topic":          "topic",
+	"event_type":     "event_type",
+	"source":         "source",
+	"subject":        "subject",
+	"correlation_id": "correlation_id",
+	"timestamp":      "event_time",
+}
+
+// sqlTypes maps condition value types to the type they are compared as
+var sqlTypes = map[string]string{
+	"string":  "text",
+	"number":  "numeric",
+	"boolean": "boolean",
+	"date":    "timestamptz",
+}
+
+// comparisons maps the scalar condition operators to SQL
+var comparisons = map[string]string{
+	"eq":  "=",
+	"ne":  "IS DISTINCT FROM",
+	"gt":  ">",
+	"lt":  "<",
+	"gte": ">=",
+	"lte": "<=",
+}
+
+// normalize applies the default and maximum limit and checks the page bounds
+func (f Filter) normalize() (Filter, error) {
+	switch {
+	case f.Limit < 0:
+		return f, fmt.Errorf("%w: limit must not be negative", ErrInvalidFilter)
+	case f.Offset < 0:
+		return f, fmt.Errorf("%w: offset must not be negative", ErrInvalidFilter)
+	case f.Offset > MaxOffset:
+		return f, fmt.Errorf("%w: offset must be at most %d", ErrInvalidFilter, MaxOffset)
+	}
+	if f.Limit == 0 {
+		f.Limit = DefaultLimit
+	}
+	if f.Limit > MaxLimit {
+		f.Limit = MaxLimit
+	}
+	if tr := f.TimeRange; tr != nil && !tr.From.IsZero() && !tr.To.IsZero() && tr.To.Before(tr.From) {
+		return f, fmt.Errorf("%w: time range ends before it starts", ErrInvalidFilter)
+	}
+	return f, nil
+}
+
+// queryBuilder collects the WHERE clauses of a search and their arguments
+type queryBuilder struct {
+	where []string
+	args  []interface{}
+}
+
+// arg adds an argument and returns its placeholder
+func (q *queryBuilder) arg(value interface{}) string {
+	q.args = append(q.args, value)
+	return fmt.Sprintf("$%d", len(q.args))
+}
+
+// buildSearchQuery builds the query of a normalized filter. Events are
+// returned newest first.
+func buildSearchQuery(f Filter) (string, []interface{}, error) {
+	q := &queryBuilder{}
+
+	if len(f.EventTypes) > 0 {
+		q.where = append(q.where, "event_type = ANY("+q.arg(pq.Array(f.EventTypes))+")")
+	}
+	if len(f.Sources) > 0 {
+		q.where = append(q.where, "source = ANY("+q.arg(pq.Array(f.Sources))+")")
+	}
+	// Tables and operations are those of the change events of Debezium
+	if len(f.Tables) > 0 {
+		q.where = append(q.where, "payload #>> '{source,table}' = ANY("+q.arg(pq.Array(f.Tables))+")")
+	}
+	if len(f.Operations) > 0 {
+		q.where = append(q.where, "payload ->> 'op' = ANY("+q.arg(pq.Array(f.Operations))+")")
+	}
+	if tr := f.TimeRange; tr != nil {
+		if !tr.From.IsZero() {
+			q.where = append(q.where, "event_time >= "+q.arg(tr.From))
+		}
+		if !tr.To.IsZero() {
+			q.where = append(q.where, "event_time <= "+q.arg(tr.To))
+		}
+	}
+	for i, condition := range f.Conditions {
+		clause, err := q.condition(condition)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: condition %d: %v", ErrInvalidFilter, i+1, err)
+		}
+		q.where = append(q.where, clause)
+	}
+
+	var b strings.Builder
+	b.WriteString("SELECT ")
+	b.WriteString(strings.Join(eventColumns, ", "))
+	b.WriteString(" FROM event_store")
+	if len(q.where) > 0 {
+		b.WriteString(" WHERE ")
+		b.WriteString(strings.Join(q.where, " AND "))
+	}
+	b.WriteString(" ORDER BY event_time DESC, event_id")
+	b.WriteString(" LIMIT " + q.arg(f.Limit))
+	b.WriteString(" OFFSET " + q.arg(f.Offset))
+	return b.String(), q.args, nil
+}
+
+// condition builds the clause of one condition
+func (q *queryBuilder) condition(c events.FilterCondition) (string, error) {
+	valueType := c.Type
+	var expr string
+	switch {
+	case strings.HasPrefix(c.Field, "data."):
+		path := strings.Split(strings.TrimPrefix(c.Field, "data."), ".")
+		if valueType == "" {
+			valueType = inferType(c.Value)
+		}
+		expr = q.dataField(path, valueType)
+	case strings.HasPrefix(c.Field, "headers."):
+		if valueType != "" && valueType != "string" {
+			return "", fmt.Errorf("header %s is a string", c.Field)
+		}
+		valueType = "string"
+		expr = "(headers ->> " + q.arg(strings.TrimPrefix(c.Field, "headers.")) + ")"
+	default:
+		column, ok := columnFields[c.Field]
+		if !ok {
+			return "", fmt.Errorf("unknown field %q", c.Field)
+		}
+		columnType := "string"
+		if column == "event_time" {
+			columnType = "date"
+		}
+		if valueType != "" && valueType != columnType {
+			return "", fmt.Errorf("field %s is a %s", c.Field, columnType)
+		}
+		valueType = columnType
+		expr = column
+	}
+
+	sqlType, ok := sqlTypes[valueType]
+	if !ok {
+		return "", fmt.Errorf("unknown type %q", valueType)
+	}
+
+	switch c.Operator {
+	case "eq", "ne", "gt", "lt", "gte", "lte":
+		value, err := literal(c.Value, valueType)
+		if err != nil {
+			return "", err
+		}
+		return expr + " " + comparisons[c.Operator] + " " + q.arg(value) + "::" + sqlType, nil
+	case "in", "nin":
+		values, ok := c.Value.([]interface{})
+		if !ok || len(values) == 0 {
+			return "", fmt.Errorf("operator %s needs a non-empty list of values", c.Operator)
+		}
+		literals := make([]string, len(values))
+		for i, value := range values {
+			var err error
+			if literals[i], err = literal(value, valueType); err != nil {
+				return "", err
+			}
+		}
+		list := q.arg(pq.Array(literals)) + "::" + sqlType + "[]"
+		if c.Operator == "in" {
+			return expr + " = ANY(" + list + ")", nil
+		}
+		return "(" + expr + " IS NULL OR " + expr + " <> ALL(" + list + "))", nil
+	case "regex":
+		if valueType != "string" {
+			return "", fmt.Errorf("operator regex applies to strings")
+		}
+		pattern, ok := c.Value.(string)
+		if !ok {
+			return "", fmt.Errorf("operator regex needs a string pattern")
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return "", fmt.Errorf("invalid pattern: %v", err)
+		}
+		return expr + " ~ " + q.arg(pattern), nil
+	default:
+		return "", fmt.Errorf("unknown operator %q", c.Operator)
+	}
+}
+
+// dataField returns the expression of a path into the event data, as a
+// valueType. Values of another JSON type are NULL rather than a cast error.
+func (q *queryBuilder) dataField(path []string, valueType string) string {
+	p := q.arg(pq.Array(path))
+	text := "(payload #>> " + p + ")"
+	switch valueType {
+	case "number":
+		return "(CASE WHEN jsonb_typeof(payload #> " + p + ") = 'number' THEN " + text + "::numeric END)"
+	case "boolean":
+		return "(CASE WHEN jsonb_typeof(payload #> " + p + ") = 'boolean' THEN " + text + "::boolean END)"
+	case "date":
+		return "(CASE WHEN " + text + " ~ '^[0-9]{4}-[0-9]{2}-[0-9]{2}' THEN " + text + "::timestamptz END)"
+	default:
+		return text
+	}
+}
+
+// inferType returns the type of a condition value without an explicit type.
+// Dates are strings in JSON and need an explicit type.
+func inferType(value interface{}) string {
+	if values, ok := value.([]interface{}); ok && len(values) > 0 {
+		value = values[0]
+	}
+	switch value.(type) {
+	case float64, json.Number, int, int64:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return "string"
+	}
+}
+
+// literal formats value as the text of a valueType, which the query casts
+func literal(value interface{}, valueType string) (string, error) {
+	switch valueType {
+	case "string":
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+	case "number":
+		switch v := value.(type) {
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		case json.Number:
+			return v.String(), nil
+		case int:
+			return strconv.Itoa(v), nil
+		case int64:
+			return strconv.FormatInt(v, 10), nil
+		}
+	case "boolean":
+		if b, ok := value.(bool); ok {
+			return strconv.FormatBool(b), nil
+		}
+	case "date":
+		switch v := value.(type) {
+		case time.Time:
+			return v.Format(time.RFC3339Nano), nil
+		case string:
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return "", fmt.Errorf("invalid date %q: expected RFC 3339", v)
+			}
+			return t.Format(time.RFC3339Nano), nil
+		}
+	}
+	return "", fmt.Errorf("value %v is not a %s", value, valueType)
+}
+
+// project renders event as returned by the filter API, keeping only the
+// include fields when set and otherwise dropping the exclude fields. Fields
+// are named as in conditions.
+func (f Filter) project(event *Event) map[string]interface{} {
+	var data interface{}
+	if len(event.Payload) > 0 {
+		decoder := json.NewDecoder(strings.NewReader(string(event.Payload)))
+		decoder.UseNumber()
+		if err := decoder.Decode(&data); err != nil {
+			data = string(event.Payload)
+		}
+	}
+	headers := make(map[string]interface{}, len(event.Headers))
+	for k, v := range event.Headers {
+		headers[k] = v
+	}
+
+	doc := map[string]interface{}{
+		"id":             event.ID,
+		"topic":          event.Topic,
+		"event_type":     event.EventType,
+		"source":         event.Source,
+		"subject":        event.Subject,
+		"correlation_id": event.CorrelationID,
+		"timestamp":      event.Timestamp,
+		"headers":        headers,
+		"data":           data,
+	}
+
+	if len(f.IncludeFields) > 0 {
+		included := make(map[string]interface{})
+		for _, field := range f.IncludeFields {
+			path := strings.Split(field, ".")
+			if value, ok := lookup(doc, path); ok {
+				assign(included, path, value)
+			}
+		}
+		return included
+	}
+	for _, field := range f.ExcludeFields {
+		remove(doc, strings.Split(field, "."))
+	}
+	return doc
+}
+
+func lookup(doc map[string]interface{}, path []string) (interface{}, bool) {
+	value, ok := doc[path[0]]
+	if !ok || len(path) == 1 {
+		return value, ok
+	}
+	child, isObject := value.(map[string]interface{})
+	if !isObject {
+		return nil, false
+	}
+	return lookup(child, path[1:])
+}
+
+func assign(doc map[string]interface{}, path []string, value interface{}) {
+	if len(path) == 1 {
+		doc[path[0]] = value
+		return
+	}
+	child, ok := doc[path[0]].(map[string]interface{})
+	if !ok {
+		child = make(map[string]interface{})
+		doc[path[0]] = child
+	}
+	assign(child, path[1:], value)
+}
+
+func remove(doc map[string]interface{}, path []string) {
+	if len(path) == 1 {
+		delete(doc, path[0])
+		return
+	}
+	if child, ok := doc[path[0]].(map[string]interface{}); ok {
+		remove(child, path[1:])
+	}
+}
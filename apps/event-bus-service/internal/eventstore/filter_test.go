@@ -0,0 +1,279 @@
+package eventstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/events"
+)
+
+func conditionFilter(conditions ...events.FilterCondition) Filter {
+	return Filter{EventFilter: events.EventFilter{Conditions: conditions}}
+}
+
+func TestBuildSearchQueryOperators(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition events.FilterCondition
+		clause    string
+		args      []interface{}
+	}{
+		{
+			name:      "eq on a column",
+			condition: events.FilterCondition{Field: "source", Operator: "eq", Value: "form-service"},
+			clause:    "source = $1::text",
+			args:      []interface{}{"form-service"},
+		},
+		{
+			name:      "ne on a data field",
+			condition: events.FilterCondition{Field: "data.status", Operator: "ne", Value: "draft"},
+			clause:    "(payload #>> $1) IS DISTINCT FROM $2::text",
+			args:      []interface{}{pq.Array([]string{"status"}), "draft"},
+		},
+		{
+			name:      "gt on a nested number",
+			condition: events.FilterCondition{Field: "data.score.total", Operator: "gt", Value: 4.5},
+			clause:    "(CASE WHEN jsonb_typeof(payload #> $1) = 'number' THEN (payload #>> $1)::numeric END) > $2::numeric",
+			args:      []interface{}{pq.Array([]string{"score", "total"}), "4.5"},
+		},
+		{
+			name:      "lt on the timestamp",
+			condition: events.FilterCondition{Field: "timestamp", Operator: "lt", Value: "2026-05-01T12:00:00Z"},
+			clause:    "event_time < $1::timestamptz",
+			args:      []interface{}{"2026-05-01T12:00:00Z"},
+		},
+		{
+			name:      "gte on a data date",
+			condition: events.FilterCondition{Field: "data.submitted_at", Operator: "gte", Value: "2026-05-01T00:00:00+02:00", Type: "date"},
+			clause:    "(CASE WHEN (payload #>> $1) ~ '^[0-9]{4}-[0-9]{2}-[0-9]{2}' THEN (payload #>> $1)::timestamptz END) >= $2::timestamptz",
+			args:      []interface{}{pq.Array([]string{"submitted_at"}), "2026-05-01T00:00:00+02:00"},
+		},
+		{
+			name:      "lte on a boolean",
+			condition: events.FilterCondition{Field: "data.anonymous", Operator: "lte", Value: true},
+			clause:    "(CASE WHEN jsonb_typeof(payload #> $1) = 'boolean' THEN (payload #>> $1)::boolean END) <= $2::boolean",
+			args:      []interface{}{pq.Array([]string{"anonymous"}), "true"},
+		},
+		{
+			name:      "in on a column",
+			condition: events.FilterCondition{Field: "event_type", Operator: "in", Value: []interface{}{"form.created", "form.updated"}},
+			clause:    "event_type = ANY($1::text[])",
+			args:      []interface{}{pq.Array([]string{"form.created", "form.updated"})},
+		},
+		{
+			name:      "nin on data numbers",
+			condition: events.FilterCondition{Field: "data.rating", Operator: "nin", Value: []interface{}{1.0, 2.0}},
+			clause:    "((CASE WHEN jsonb_typeof(payload #> $1) = 'number' THEN (payload #>> $1)::numeric END) IS NULL OR (CASE WHEN jsonb_typeof(payload #> $1) = 'number' THEN (payload #>> $1)::numeric END) <> ALL($2::numeric[]))",
+			args:      []interface{}{pq.Array([]string{"rating"}), pq.Array([]string{"1", "2"})},
+		},
+		{
+			name:      "regex on a header",
+			condition: events.FilterCondition{Field: "headers.tenant", Operator: "regex", Value: "^acme-"},
+			clause:    "(headers ->> $1) ~ $2",
+			args:      []interface{}{"tenant", "^acme-"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := conditionFilter(tt.condition).normalize()
+			if err != nil {
+				t.Fatal(err)
+			}
+			query, args, err := buildSearchQuery(filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(query, " WHERE "+tt.clause+" ORDER BY") {
+				t.Errorf("query = %s\nwant clause %s", query, tt.clause)
+			}
+			want := append(tt.args, DefaultLimit, 0)
+			if !reflect.DeepEqual(args, want) {
+				t.Errorf("args = %#v, want %#v", args, want)
+			}
+		})
+	}
+}
+
+func TestBuildSearchQueryFilters(t *testing.T) {
+	from := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	filter, err := Filter{
+		EventFilter: events.EventFilter{
+			EventTypes: []string{"form.created"},
+			Sources:    []string{"form-service"},
+			Tables:     []string{"forms"},
+			Operations: []string{"c", "u"},
+			TimeRange:  &events.TimeRange{From: from, To: to},
+		},
+		Limit:  50,
+		Offset: 100,
+	}.normalize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query, args, err := buildSearchQuery(filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "SELECT event_id, topic, event_type, source, subject, correlation_id, payload, headers, event_time FROM event_store" +
+		" WHERE event_type = ANY($1) AND source = ANY($2) AND payload #>> '{source,table}' = ANY($3)" +
+		" AND payload ->> 'op' = ANY($4) AND event_time >= $5 AND event_time <= $6" +
+		" ORDER BY event_time DESC, event_id LIMIT $7 OFFSET $8"
+	if query != want {
+		t.Errorf("query = %s\nwant    %s", query, want)
+	}
+	if len(args) != 8 || args[4] != from || args[5] != to || args[6] != 50 || args[7] != 100 {
+		t.Errorf("args = %#v", args)
+	}
+}
+
+func TestFilterLimits(t *testing.T) {
+	filter, err := Filter{Limit: 50000}.normalize()
+	if err != nil || filter.Limit != MaxLimit {
+		t.Errorf("limit = %d, %v; want the limit capped at %d", filter.Limit, err, MaxLimit)
+	}
+	if filter, _ := (Filter{}).normalize(); filter.Limit != DefaultLimit {
+		t.Errorf("limit = %d, want %d by default", filter.Limit, DefaultLimit)
+	}
+	if _, err := (Filter{Offset: MaxOffset + 1}).normalize(); !errors.Is(err, ErrInvalidFilter) {
+		t.Errorf("expected a deep offset to be rejected, got %v", err)
+	}
+}
+
+func TestBuildSearchQueryRejectsInvalidConditions(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition events.FilterCondition
+		want      string
+	}{
+		{"unknown field", events.FilterCondition{Field: "payload", Operator: "eq", Value: "x"}, `unknown field "payload"`},
+		{"unknown operator", events.FilterCondition{Field: "source", Operator: "like", Value: "x"}, `unknown operator "like"`},
+		{"invalid pattern", events.FilterCondition{Field: "source", Operator: "regex", Value: "("}, "invalid pattern"},
+		{"regex on a number", events.FilterCondition{Field: "data.rating", Operator: "regex", Value: 1.0}, "regex applies to strings"},
+		{"in without a list", events.FilterCondition{Field: "source", Operator: "in", Value: "x"}, "non-empty list"},
+		{"mistyped value", events.FilterCondition{Field: "data.rating", Operator: "gt", Value: "high", Type: "number"}, "is not a number"},
+		{"column type", events.FilterCondition{Field: "timestamp", Operator: "eq", Value: 1.0, Type: "number"}, "timestamp is a date"},
+		{"invalid date", events.FilterCondition{Field: "timestamp", Operator: "gt", Value: "yesterday"}, "expected RFC 3339"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := buildSearchQuery(conditionFilter(tt.condition))
+			if !errors.Is(err, ErrInvalidFilter) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected ErrInvalidFilter containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestProject(t *testing.T) {
+	event := &Event{
+		ID:        "event_1",
+		Topic:     "app.form.created",
+		EventType: "form.created",
+		Source:    "form-service",
+		Payload:   json.RawMessage(`{"form_id":"form-1","owner":{"id":"user-1","email":"a@example.com"},"rating":4}`),
+		Headers:   map[string]string{"tenant": "acme"},
+		Timestamp: time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	included := Filter{EventFilter: events.EventFilter{IncludeFields: []string{"id", "data.owner.id", "data.missing"}}}.project(event)
+	want := map[string]interface{}{
+		"id":   "event_1",
+		"data": map[string]interface{}{"owner": map[string]interface{}{"id": "user-1"}},
+	}
+	if !reflect.DeepEqual(included, want) {
+		t.Errorf("included = %#v, want %#v", included, want)
+	}
+
+	excluded := Filter{EventFilter: events.EventFilter{ExcludeFields: []string{"headers", "data.owner.email"}}}.project(event)
+	if _, ok := excluded["headers"]; ok {
+		t.Error("headers were not excluded")
+	}
+	owner := excluded["data"].(map[string]interface{})["owner"].(map[string]interface{})
+	if _, ok := owner["email"]; ok || owner["id"] != "user-1" {
+		t.Errorf("owner = %#v, want only the email excluded", owner)
+	}
+}
+
+// fakeRows serves events as *sql.Rows would
+type fakeRows struct {
+	events []Event
+	next   int
+	closed bool
+}
+
+func (r *fakeRows) Next() bool {
+	r.next++
+	return r.next <= len(r.events)
+}
+
+func (r *fakeRows) Scan(dest ...interface{}) error {
+	event := r.events[r.next-1]
+	headers, _ := json.Marshal(event.Headers)
+	values := []interface{}{event.ID, event.Topic, event.EventType, event.Source, event.Subject,
+		event.CorrelationID, []byte(event.Payload), headers, event.Timestamp}
+	for i, value := range values {
+		reflect.ValueOf(dest[i]).Elem().Set(reflect.ValueOf(value))
+	}
+	return nil
+}
+
+func (r *fakeRows) Err() error   { return nil }
+func (r *fakeRows) Close() error { r.closed = true; return nil }
+
+func TestWritePage(t *testing.T) {
+	stored := make([]Event, 3)
+	for i := range stored {
+		stored[i] = Event{ID: "event_" + string(rune('1'+i)), EventType: "form.created", Payload: json.RawMessage(`{"n":1}`)}
+	}
+
+	tests := []struct {
+		name       string
+		events     []Event
+		nextOffset interface{}
+	}{
+		{"full page", stored[:2], 12.0},
+		{"last page", stored[:1], nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := Filter{Limit: 2, Offset: 10, EventFilter: events.EventFilter{IncludeFields: []string{"id"}}}
+			cursor := &Cursor{rows: &fakeRows{events: tt.events}, filter: filter}
+
+			var buf bytes.Buffer
+			count, err := cursor.WritePage(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var page struct {
+				Events     []map[string]interface{} `json:"events"`
+				Count      int                      `json:"count"`
+				Limit      int                      `json:"limit"`
+				Offset     int                      `json:"offset"`
+				NextOffset interface{}              `json:"next_offset"`
+			}
+			if err := json.Unmarshal(buf.Bytes(), &page); err != nil {
+				t.Fatalf("page is not valid JSON: %v\n%s", err, buf.String())
+			}
+			if count != len(tt.events) || page.Count != count || page.Limit != 2 || page.Offset != 10 {
+				t.Errorf("page = %+v, count %d", page, count)
+			}
+			if page.NextOffset != tt.nextOffset {
+				t.Errorf("next_offset = %v, want %v", page.NextOffset, tt.nextOffset)
+			}
+			if len(page.Events) != len(tt.events) || page.Events[0]["id"] != "event_1" || len(page.Events[0]) != 1 {
+				t.Errorf("events = %v", page.Events)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package eventstore
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// purgeBatchSize bounds the events deleted by one statement, so purging a
+// large backlog never holds long locks on the table
+const purgeBatchSize = 5000
+
+// PurgeExpired deletes the events older than retention at now, in batches,
+// and returns the number deleted
+func PurgeExpired(ctx context.Context, store Store, retention time.Duration, now time.Time) (int64, error) {
+	before := now.Add(-retention)
+
+	var purged int64
+	for {
+		deleted, err := store.Purge(ctx, before, purgeBatchSize)
+		purged += deleted
+		if err != nil {
+			return purged, err
+		}
+		if deleted < purgeBatchSize {
+			return purged, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return purged, err
+		}
+	}
+}
+
+// RunRetention purges expired events every interval until ctx is done. A
+// failed purge is logged and retried on the next tick.
+func RunRetention(ctx context.Context, store Store, retention, interval time.Duration, logger *zap.Logger) {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := PurgeExpired(ctx, store, retention, time.Now())
+		switch {
+		case err != nil && ctx.Err() == nil:
+			logger.Error("Failed to purge expired events", zap.Int64("purged", purged), zap.Error(err))
+		case purged > 0:
+			logger.Info("Purged expired events", zap.Int64("purged", purged), zap.Duration("retention", retention))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package eventstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Schema creates the event_store table. It is kept in line with
+// scripts/init-db.sql so a fresh database can be written without running the
+// init script first.
+const Schema = `
+CREATE TABLE IF NOT EXISTS event_store (
+    event_id VARCHAR(255) PRIMARY KEY,
+    topic VARCHAR(255) NOT NULL,
+    event_type VARCHAR(255) NOT NULL DEFAULT '',
+    source VARCHAR(255) NOT NULL DEFAULT '',
+    subject VARCHAR(255) NOT NULL DEFAULT '',
+    correlation_id VARCHAR(255) NOT NULL DEFAULT '',
+    payload JSONB,
+    headers JSONB,
+    event_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    stored_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_event_store_event_type ON event_store(event_type);
+CREATE INDEX IF NOT EXISTS idx_event_store_source ON event_store(source);
+CREATE INDEX IF NOT EXISTS idx_event_store_event_time ON event_store(event_time);
+`
+
+// eventColumns are the columns written and read for each event, in the order
+// of the statement placeholders
+var eventColumns = []string{
+	"event_id", "topic", "event_type", "source", "subject", "correlation_id",
+	"payload", "headers", "event_time",
+}
+
+// maxEventsPerStatement keeps a single INSERT well below PostgreSQL's limit
+// of 65535 bind parameters
+const maxEventsPerStatement = 1000
+
+// Store persists events
+type Store interface {
+	// InsertEvents writes events, skipping any whose ID is already stored,
+	// and returns the number of events actually inserted
+	InsertEvents(ctx context.Context, events []Event) (int64, error)
+	// Purge deletes at most limit events older than before and returns the
+	// number deleted
+	Purge(ctx context.Context, before time.Time, limit int) (int64, error)
+}
+
+// Searcher filters stored events
+type Searcher interface {
+	// Search returns a cursor over the page of events matching filter
+	Search(ctx context.Context, filter Filter) (*Cursor, error)
+}
+
+// PostgresStore keeps events in the event_store table
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore creates a store writing through db
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// EnsureSchema creates the event_store table and its indexes if missing
+func (s *PostgresStore) EnsureSchema(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, Schema); err != nil {
+		return fmt.Errorf("failed to create event_store table: %w", err)
+	}
+	return nil
+}
+
+// InsertEvents writes events with multi-row inserts in one transaction, so a
+// batch is either fully stored or not at all
+func (s *PostgresStore) InsertEvents(ctx context.Context, events []Event) (int64, error) {
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var inserted int64
+	for start := 0; start < len(events); start += maxEventsPerStatement {
+		end := start + maxEventsPerStatement
+		if end > len(events) {
+			end = len(events)
+		}
+
+		query, args, err := buildInsertStatement(events[start:end])
+		if err != nil {
+			return 0, err
+		}
+		result, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return 0, fmt.Errorf("failed to insert events: %w", err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("failed to count inserted events: %w", err)
+		}
+		inserted += affected
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit events: %w", err)
+	}
+	return inserted, nil
+}
+
+// Purge deletes at most limit events older than before
+func (s *PostgresStore) Purge(ctx context.Context, before time.Time, limit int) (int64, error) {
+	result, err := s.db.ExecContext(ctx,
+		"DELETE FROM event_store WHERE event_id IN (SELECT event_id FROM event_store WHERE event_time < $1 LIMIT $2)",
+		before, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge events: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// Search runs the query of filter. The returned cursor reads the matching
+// events one row at a time and must be closed.
+func (s *PostgresStore) Search(ctx context.Context, filter Filter) (*Cursor, error) {
+	filter, err := filter.normalize()
+	if err != nil {
+		return nil, err
+	}
+	query, args, err := buildSearchQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search events: %w", err)
+	}
+	return &Cursor{rows: rows, filter: filter}, nil
+}
+
+// buildInsertStatement builds one INSERT ... ON CONFLICT DO NOTHING for events
+func buildInsertStatement(events []Event) (string, []interface{}, error) {
+	var b strings.Builder
+	args := make([]interface{}, 0, len(events)*len(eventColumns))
+
+	b.WriteString("INSERT INTO event_store (")
+	b.WriteString(strings.Join(eventColumns, ", "))
+	b.WriteString(") VALUES ")
+
+	for i, event := range events {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString("(")
+		for j := range eventColumns {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, "$%d", i*len(eventColumns)+j+1)
+		}
+		b.WriteString(")")
+
+		headers, err := json.Marshal(event.Headers)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to encode headers of event %s: %w", event.ID, err)
+		}
+		args = append(args,
+			event.ID, event.Topic, event.EventType, event.Source, event.Subject, event.CorrelationID,
+			string(event.Payload), string(headers), event.Timestamp,
+		)
+	}
+
+	b.WriteString(" ON CONFLICT (event_id) DO NOTHING")
+	return b.String(), args, nil
+}
@@ -0,0 +1,258 @@
+package eventstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/projections"
+)
+
+// memoryStore mirrors the event_store primary key and purge in memory
+type memoryStore struct {
+	mu     sync.Mutex
+	events map[string]Event
+	purges int
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{events: make(map[string]Event)}
+}
+
+func (s *memoryStore) InsertEvents(_ context.Context, events []Event) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var inserted int64
+	for _, event := range events {
+		if _, ok := s.events[event.ID]; ok {
+			continue
+		}
+		s.events[event.ID] = event
+		inserted++
+	}
+	return inserted, nil
+}
+
+func (s *memoryStore) Purge(_ context.Context, before time.Time, limit int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.purges++
+	var deleted int64
+	for id, event := range s.events {
+		if deleted == int64(limit) {
+			break
+		}
+		if event.Timestamp.Before(before) {
+			delete(s.events, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (s *memoryStore) ids() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]string, 0, len(s.events))
+	for id := range s.events {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestSinkStoresEventsOnce(t *testing.T) {
+	store := newMemoryStore()
+	metrics := projections.NewMetrics(prometheus.NewRegistry())
+	sink := NewSink(config.EventStoreSinkConfig{Topics: []string{"app.form.created"}, GroupID: "event-store"}, store, metrics, nil)
+
+	messages := []*kafka.Message{
+		{ID: "event_1", EventType: "form.created", Topic: "app.form.created", Data: map[string]interface{}{"form_id": "form-1"}},
+		{ID: "event_2", EventType: "form.created", Topic: "app.form.created", Data: []byte("not json")},
+		{EventType: "form.created", Topic: "app.form.created"},
+	}
+	for i := 0; i < 2; i++ {
+		if err := sink.HandleBatch(context.Background(), messages); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if ids := store.ids(); len(ids) != 2 {
+		t.Fatalf("stored %v, want event_1 and event_2", ids)
+	}
+	if payload := string(store.events["event_2"].Payload); payload != `"not json"` {
+		t.Errorf("opaque payload stored as %s", payload)
+	}
+	if got := testutil.ToFloat64(metrics.DuplicateRows.WithLabelValues(sinkName)); got != 2 {
+		t.Errorf("duplicates = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(metrics.Events.WithLabelValues(sinkName, "invalid")); got != 2 {
+		t.Errorf("invalid events = %v, want 2", got)
+	}
+}
+
+func TestPurgeExpired(t *testing.T) {
+	store := newMemoryStore()
+	now := time.Date(2026, 5, 10, 0, 0, 0, 0, time.UTC)
+
+	var events []Event
+	for i := 0; i < purgeBatchSize+10; i++ {
+		events = append(events, Event{ID: fmt.Sprintf("expired_%05d", i), Timestamp: now.Add(-8 * 24 * time.Hour)})
+	}
+	events = append(events, Event{ID: "recent", Timestamp: now.Add(-time.Hour)})
+	if _, err := store.InsertEvents(context.Background(), events); err != nil {
+		t.Fatal(err)
+	}
+
+	purged, err := PurgeExpired(context.Background(), store, 7*24*time.Hour, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != purgeBatchSize+10 {
+		t.Errorf("purged %d events, want %d", purged, purgeBatchSize+10)
+	}
+	if store.purges != 2 {
+		t.Errorf("purged in %d batches, want 2", store.purges)
+	}
+	if ids := store.ids(); len(ids) != 1 || ids[0] != "recent" {
+		t.Errorf("remaining events = %v, want only the recent one", ids)
+	}
+}
+
+func TestRunRetentionPurgesUntilStopped(t *testing.T) {
+	store := newMemoryStore()
+	if _, err := store.InsertEvents(context.Background(), []Event{
+		{ID: "expired", Timestamp: time.Now().Add(-2 * time.Hour)},
+		{ID: "recent", Timestamp: time.Now()},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		RunRetention(ctx, store, time.Hour, 10*time.Millisecond, nil)
+		close(done)
+	}()
+
+	deadline := time.After(5 * time.Second)
+	for len(store.ids()) != 1 {
+		select {
+		case <-deadline:
+			t.Fatalf("expired event not purged: %v", store.ids())
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("retention did not stop")
+	}
+}
+
+// TestPostgresStore runs every operator and the retention purge against a
+// real database when EVENTBUS_TEST_DATABASE_URL is set
+func TestPostgresStore(t *testing.T) {
+	dsn := os.Getenv("EVENTBUS_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("EVENTBUS_TEST_DATABASE_URL not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if _, err := db.ExecContext(ctx, "DROP TABLE IF EXISTS event_store"); err != nil {
+		t.Fatalf("failed to reset table: %v", err)
+	}
+	store := NewPostgresStore(db)
+	if err := store.EnsureSchema(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	base := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	stored := []Event{
+		{ID: "e1", Topic: "app.form.created", EventType: "form.created", Source: "form-service",
+			Payload: json.RawMessage(`{"rating":5,"public":true,"owner":{"name":"ada"}}`), Headers: map[string]string{"tenant": "acme"}, Timestamp: base},
+		{ID: "e2", Topic: "app.form.updated", EventType: "form.updated", Source: "form-service",
+			Payload: json.RawMessage(`{"rating":3,"public":false,"owner":{"name":"grace"}}`), Headers: map[string]string{"tenant": "globex"}, Timestamp: base.Add(time.Hour)},
+		{ID: "e3", Topic: "app.response.created", EventType: "response.created", Source: "response-service",
+			Payload: json.RawMessage(`{"rating":"n/a"}`), Timestamp: base.Add(2 * time.Hour)},
+	}
+	if inserted, err := store.InsertEvents(ctx, append(stored, stored[0])); err != nil || inserted != 3 {
+		t.Fatalf("inserted %d events: %v", inserted, err)
+	}
+
+	tests := []struct {
+		name      string
+		condition events.FilterCondition
+		want      []string
+	}{
+		{"eq", events.FilterCondition{Field: "data.owner.name", Operator: "eq", Value: "ada"}, []string{"e1"}},
+		{"ne", events.FilterCondition{Field: "source", Operator: "ne", Value: "form-service"}, []string{"e3"}},
+		{"gt", events.FilterCondition{Field: "data.rating", Operator: "gt", Value: 3.0}, []string{"e1"}},
+		{"lt", events.FilterCondition{Field: "timestamp", Operator: "lt", Value: base.Add(time.Hour).Format(time.RFC3339)}, []string{"e1"}},
+		{"gte", events.FilterCondition{Field: "data.rating", Operator: "gte", Value: 3.0}, []string{"e2", "e1"}},
+		{"lte", events.FilterCondition{Field: "data.public", Operator: "lte", Value: false}, []string{"e2"}},
+		{"in", events.FilterCondition{Field: "event_type", Operator: "in", Value: []interface{}{"form.created", "response.created"}}, []string{"e3", "e1"}},
+		{"nin", events.FilterCondition{Field: "data.rating", Operator: "nin", Value: []interface{}{5.0}}, []string{"e3", "e2"}},
+		{"regex", events.FilterCondition{Field: "headers.tenant", Operator: "regex", Value: "^glo"}, []string{"e2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor, err := store.Search(ctx, conditionFilter(tt.condition))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer cursor.Close()
+
+			var got []string
+			for cursor.Next() {
+				got = append(got, cursor.Event().ID)
+			}
+			if err := cursor.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("matched %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	purged, err := PurgeExpired(ctx, store, time.Hour, base.Add(2*time.Hour+time.Minute))
+	if err != nil || purged != 2 {
+		t.Fatalf("purged %d events: %v", purged, err)
+	}
+	var remaining int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM event_store").Scan(&remaining); err != nil {
+		t.Fatal(err)
+	}
+	if remaining != 1 {
+		t.Errorf("%d events remain after the purge, want 1", remaining)
+	}
+
+	if _, err := store.Search(ctx, conditionFilter(events.FilterCondition{Field: "nope", Operator: "eq", Value: "x"})); !errors.Is(err, ErrInvalidFilter) {
+		t.Errorf("expected ErrInvalidFilter, got %v", err)
+	}
+}
@@ -0,0 +1,249 @@
+// Package grpcserver exposes event publishing over gRPC for internal services
+// that publish at high volume. It shares validation and the Kafka publishing
+// path with the HTTP API through the publishing package.
+package grpcserver
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/acl"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/publishing"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/schemas"
+	"github.com/Mir00r/X-Form-Backend/shared/eventbus/eventbusv1"
+)
+
+// Server implements the EventBus gRPC service
+type Server struct {
+	eventbusv1.UnimplementedEventBusServer
+
+	publisher *publishing.Publisher
+	auth      *acl.Authenticator
+	logger    *zap.Logger
+}
+
+// NewServer creates a new EventBus service implementation
+func NewServer(publisher *publishing.Publisher, logger *zap.Logger) *Server {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	return &Server{
+		publisher: publisher,
+		logger:    logger,
+	}
+}
+
+// SetAuthenticator identifies the callers with auth, from their client
+// certificate and their x-api-key or authorization metadata, so the publish
+// ACL applies to them
+func (s *Server) SetAuthenticator(auth *acl.Authenticator) {
+	s.auth = auth
+}
+
+// withCaller returns ctx carrying the caller of the call, when callers are
+// identified
+func (s *Server) withCaller(ctx context.Context) context.Context {
+	if s.auth == nil {
+		return ctx
+	}
+	caller := &acl.Caller{}
+	var state *tls.ConnectionState
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			state = &info.State
+		}
+		if p.Addr != nil {
+			caller.IP = p.Addr.String()
+			if host, _, err := net.SplitHostPort(caller.IP); err == nil {
+				caller.IP = host
+			}
+		}
+	}
+	var apiKey, bearer string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("x-api-key"); len(values) > 0 {
+			apiKey = values[0]
+		}
+		if values := md.Get("authorization"); len(values) > 0 {
+			bearer, _ = strings.CutPrefix(values[0], "Bearer ")
+		}
+		if values := md.Get("x-request-id"); len(values) > 0 {
+			caller.RequestID = values[0]
+		}
+	}
+	caller.Principals = s.auth.Principals(state, apiKey, bearer)
+	return acl.NewContext(ctx, caller)
+}
+
+// PublishEvent publishes a single event
+func (s *Server) PublishEvent(ctx context.Context, req *eventbusv1.EventRequest) (*eventbusv1.PublishEventResponse, error) {
+	event := fromProto(req)
+	result, err := s.publisher.Publish(s.withCaller(ctx), publishing.ProtocolGRPC, &event)
+	if errors.Is(err, publishing.ErrInvalidEvent) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if errors.Is(err, acl.ErrForbidden) {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	if errors.Is(err, kafka.ErrMessageTooLarge) {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	}
+	if errors.Is(err, schemas.ErrIncompatible) {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	// Unavailable tells clients to retry with backoff
+	if errors.Is(err, kafka.ErrBackpressure) {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	if err != nil {
+		s.logger.Error("Failed to publish event", zap.String("event_type", req.GetEventType()), zap.Error(err))
+		return nil, status.Error(codes.Unavailable, "failed to publish event")
+	}
+
+	return &eventbusv1.PublishEventResponse{
+		EventId: result.EventID,
+		Topic:   result.Topic,
+		Status:  result.Status,
+	}, nil
+}
+
+// PublishEventBatch publishes every event of the batch independently
+func (s *Server) PublishEventBatch(ctx context.Context, req *eventbusv1.PublishEventBatchRequest) (*eventbusv1.PublishEventBatchResponse, error) {
+	events := make([]publishing.EventRequest, len(req.GetEvents()))
+	for i, event := range req.GetEvents() {
+		events[i] = fromProto(event)
+	}
+
+	batch, err := s.publisher.PublishBatch(s.withCaller(ctx), publishing.ProtocolGRPC, events)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	resp := &eventbusv1.PublishEventBatchResponse{
+		TotalEvents:      int32(batch.Total),
+		SuccessfulEvents: int32(batch.Successful),
+		FailedEvents:     int32(batch.Failed),
+		Results:          make([]*eventbusv1.EventResult, 0, len(batch.Results)),
+	}
+	for _, result := range batch.Results {
+		resp.Results = append(resp.Results, &eventbusv1.EventResult{
+			Index:   int32(result.Index),
+			EventId: result.EventID,
+			Topic:   result.Topic,
+			Status:  result.Status,
+			Error:   result.Error,
+		})
+	}
+
+	return resp, nil
+}
+
+// New creates a gRPC server with the EventBus and grpc.health.v1 services
+// registered. TLS is enabled from cfg.TLS, with client certificates required
+// when a client CA is configured.
+func New(cfg config.GRPCConfig, srv *Server, healthServer *health.Server, logger *zap.Logger) (*grpc.Server, error) {
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(loggingInterceptor(logger)),
+	}
+	if cfg.MaxRecvMsgSize > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(cfg.MaxRecvMsgSize))
+	}
+
+	if cfg.TLS.Enabled {
+		tlsConfig, err := serverTLSConfig(cfg.TLS)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	server := grpc.NewServer(opts...)
+	eventbusv1.RegisterEventBusServer(server, srv)
+	healthpb.RegisterHealthServer(server, healthServer)
+	healthServer.SetServingStatus(eventbusv1.EventBus_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+
+	return server, nil
+}
+
+// serverTLSConfig builds the TLS configuration of the gRPC listener
+func serverTLSConfig(cfg config.TLSConfig) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load gRPC certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gRPC client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
+
+// loggingInterceptor logs every unary call with its outcome and duration
+func loggingInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		logger.Info("gRPC request",
+			zap.String("method", info.FullMethod),
+			zap.String("code", status.Code(err).String()),
+			zap.Duration("duration", time.Since(start)))
+
+		return resp, err
+	}
+}
+
+// fromProto converts a gRPC event request to the shared request type
+func fromProto(req *eventbusv1.EventRequest) publishing.EventRequest {
+	event := publishing.EventRequest{
+		EventType: req.GetEventType(),
+		Source:    req.GetSource(),
+		Subject:   req.GetSubject(),
+		Headers:   req.GetHeaders(),
+		Topic:     req.GetTopic(),
+		Key:       req.GetKey(),
+	}
+	if req.GetData() != nil {
+		event.Data = req.GetData().AsMap()
+	}
+	return event
+}
@@ -0,0 +1,225 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/acl"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/publishing"
+	"github.com/Mir00r/X-Form-Backend/shared/eventbus"
+	"github.com/Mir00r/X-Form-Backend/shared/eventbus/eventbusv1"
+)
+
+// fakeProducer records published messages and fails for the configured event types
+type fakeProducer struct {
+	mu       sync.Mutex
+	messages []*kafka.Message
+	failFor  string
+}
+
+func (p *fakeProducer) PublishMessage(_ context.Context, message *kafka.Message) error {
+	if message.EventType == p.failFor {
+		return errors.New("broker unavailable")
+	}
+	if message.EventType == "oversized.event" {
+		return fmt.Errorf("%w: 2000000 bytes exceeds the limit of 1000000 bytes", kafka.ErrMessageTooLarge)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.messages = append(p.messages, message)
+	return nil
+}
+
+type testEnv struct {
+	producer *fakeProducer
+	metrics  *publishing.Metrics
+	client   *eventbus.Client
+	conn     *grpc.ClientConn
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	return newTestEnvWith(t, func(*publishing.Publisher, *Server) {})
+}
+
+// newTestEnvWith lets setup configure the publisher and the server
+func newTestEnvWith(t *testing.T, setup func(*publishing.Publisher, *Server)) *testEnv {
+	t.Helper()
+
+	producer := &fakeProducer{failFor: "broken.event"}
+	metrics := publishing.NewMetrics(prometheus.NewRegistry())
+	publisher := publishing.NewPublisher(producer, metrics)
+	srv := NewServer(publisher, nil)
+	setup(publisher, srv)
+
+	server, err := New(config.GRPCConfig{}, srv, health.NewServer(), nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	listener := bufconn.Listen(1024 * 1024)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	dialer := grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	})
+
+	client, err := eventbus.NewClient(eventbus.Config{Address: "passthrough:///bufnet"}, dialer)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	conn, err := grpc.NewClient("passthrough:///bufnet", dialer, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return &testEnv{producer: producer, metrics: metrics, client: client, conn: conn}
+}
+
+func TestPublishEvent(t *testing.T) {
+	env := newTestEnv(t)
+
+	resp, err := env.client.Publish(context.Background(), eventbus.Event{
+		Type:    "form.created",
+		Source:  "form-service",
+		Data:    map[string]interface{}{"form_id": "f-1", "questions": 3.0},
+		Headers: map[string]string{"tenant": "acme"},
+	})
+	if err != nil {
+		t.Fatalf("publish failed: %v", err)
+	}
+	if resp.GetTopic() != "app.form.created" || resp.GetStatus() != "published" || resp.GetEventId() == "" {
+		t.Errorf("unexpected response: %v", resp)
+	}
+
+	if len(env.producer.messages) != 1 {
+		t.Fatalf("expected 1 published message, got %d", len(env.producer.messages))
+	}
+	message := env.producer.messages[0]
+	data := message.Data.(map[string]interface{})
+	if data["form_id"] != "f-1" || data["questions"] != 3.0 || message.Headers["tenant"] != "acme" {
+		t.Errorf("message was not converted faithfully: %+v", message)
+	}
+
+	if got := testutil.ToFloat64(env.metrics.EventsPublished.WithLabelValues(publishing.ProtocolGRPC, "published")); got != 1 {
+		t.Errorf("expected 1 published event recorded for grpc, got %v", got)
+	}
+}
+
+func TestPublishEventValidation(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, err := env.client.Publish(context.Background(), eventbus.Event{Type: "form.created", Source: "form-service"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+
+	_, err = env.client.Publish(context.Background(), eventbus.Event{Type: "broken.event", Source: "form-service", Data: map[string]interface{}{}})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected Unavailable for a Kafka failure, got %v", err)
+	}
+
+	_, err = env.client.Publish(context.Background(), eventbus.Event{Type: "oversized.event", Source: "form-service", Data: map[string]interface{}{}})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted for an oversized event, got %v", err)
+	}
+
+	if got := testutil.ToFloat64(env.metrics.EventsPublished.WithLabelValues(publishing.ProtocolGRPC, "invalid")); got != 1 {
+		t.Errorf("expected 1 invalid event recorded, got %v", got)
+	}
+}
+
+func TestPublishEventBatch(t *testing.T) {
+	env := newTestEnv(t)
+
+	resp, err := env.client.PublishBatch(context.Background(), []eventbus.Event{
+		{Type: "response.created", Source: "response-service", Data: map[string]interface{}{"id": "r-1"}},
+		{Type: "response.created", Source: ""},
+		{Type: "broken.event", Source: "response-service", Data: map[string]interface{}{}},
+	})
+	if err != nil {
+		t.Fatalf("batch publish failed: %v", err)
+	}
+
+	if resp.GetTotalEvents() != 3 || resp.GetSuccessfulEvents() != 1 || resp.GetFailedEvents() != 2 {
+		t.Fatalf("unexpected batch counts: %v", resp)
+	}
+	for i, result := range resp.GetResults() {
+		if int(result.GetIndex()) != i {
+			t.Errorf("result %d has index %d", i, result.GetIndex())
+		}
+	}
+	if resp.GetResults()[0].GetStatus() != "published" || resp.GetResults()[1].GetError() == "" {
+		t.Errorf("unexpected results: %v", resp.GetResults())
+	}
+
+	_, err = env.client.PublishBatch(context.Background(), nil)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for an empty batch, got %v", err)
+	}
+}
+
+func TestPublishEventForbidden(t *testing.T) {
+	security := config.SecurityConfig{
+		APIKeys: config.APIKeysConfig{Enabled: true, Keys: map[string]string{"debezium": "cdc-key"}},
+		PublishACL: config.PublishACLConfig{
+			Enabled:         true,
+			DefaultAction:   "allow",
+			ProtectedTopics: []string{"cdc.*"},
+			Rules:           []config.PublishACLRule{{Principal: "api_key:debezium", Allow: []string{"cdc.*"}}},
+		},
+	}
+	env := newTestEnvWith(t, func(publisher *publishing.Publisher, srv *Server) {
+		publisher.SetAuthorizer(acl.New(security.PublishACL, acl.NewMetrics(prometheus.NewRegistry()), nil))
+		srv.SetAuthenticator(acl.NewAuthenticator(security))
+	})
+	client := eventbusv1.NewEventBusClient(env.conn)
+	event := &eventbusv1.EventRequest{EventType: "form.changed", Source: "debezium", Topic: "cdc.public.forms", Data: &structpb.Struct{}}
+
+	_, err := client.PublishEvent(context.Background(), event)
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected PermissionDenied without a key, got %v", err)
+	}
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "cdc-key")
+	if _, err := client.PublishEvent(ctx, event); err != nil {
+		t.Fatalf("publish with the allowed key failed: %v", err)
+	}
+	if got := testutil.ToFloat64(env.metrics.EventsPublished.WithLabelValues(publishing.ProtocolGRPC, "forbidden")); got != 1 {
+		t.Errorf("expected 1 forbidden event recorded, got %v", got)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	env := newTestEnv(t)
+
+	resp, err := healthpb.NewHealthClient(env.conn).Check(context.Background(), &healthpb.HealthCheckRequest{
+		Service: eventbusv1.EventBus_ServiceDesc.ServiceName,
+	})
+	if err != nil {
+		t.Fatalf("health check failed: %v", err)
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("expected SERVING, got %v", resp.GetStatus())
+	}
+}
@@ -5,18 +5,22 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
 	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/debezium"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/eventstore"
 	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
 	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/processors"
 	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
 	"go.uber.org/zap"
 )
 
@@ -28,522 +32,7 @@ type EventBusHandler struct {
 	debezium         *debezium.Manager
 	processorManager *processors.ProcessorManager
 	metrics          *HandlerMetrics
-}
-
-// HandlerMetrics contains Prometheus metrics for HTTP handlers
-type HandlerMetrics struct {
-	RequestsTotal     *prometheus.CounterVec
-	RequestDuration   *prometheus.HistogramVec
-	ErrorsTotal       *prometheus.CounterVec
-	ActiveConnections prometheus.Gauge
-}
-
-// APIResponse represents a standard API response
-type APIResponse struct {
-	Success   bool        `json:"success"`
-	Message   string      `json:"message,omitempty"`
-	Data      interface{} `json:"data,omitempty"`
-	Error     interface{} `json:"error,omitempty"`
-	Timestamp time.Time   `json:"timestamp"`
-	RequestID string      `json:"request_id,omitempty"`
-	Version   string      `json:"version"`
-}
-
-// EventRequest represents an event publishing request
-type EventRequest struct {
-	EventType string                 `json:"event_type"`
-	Source    string                 `json:"source"`
-	Subject   string                 `json:"subject"`
-	Data      map[string]interface{} `json:"data"`
-	Headers   map[string]string      `json:"headers"`
-	Topic     string                 `json:"topic"`
-	Key       string                 `json:"key"`
-}
-
-// HealthResponse represents a health check response
-type HealthResponse struct {
-	Status       string                 `json:"status"`
-	Version      string                 `json:"version"`
-	Timestamp    time.Time              `json:"timestamp"`
-	Uptime       time.Duration          `json:"uptime"`
-	Components   map[string]interface{} `json:"components"`
-	Dependencies map[string]interface{} `json:"dependencies"`
-}
-
-// NewEventBusHandler creates a new event bus handler
-func NewEventBusHandler(
-	cfg *config.Config,
-	logger *zap.Logger,
-	kafkaClient *kafka.Client,
-	debeziumManager *debezium.Manager,
-	processorManager *processors.ProcessorManager,
-) *EventBusHandler {
-	if logger == nil {
-		logger = zap.NewNop()
-	}
-
-	return &EventBusHandler{
-		config:           cfg,
-		logger:           logger,
-		kafka:            kafkaClient,
-		debezium:         debeziumManager,
-		processorManager: processorManager,
-		metrics:          initHandlerMetrics(),
-	}
-}
-
-// RegisterRoutes registers all HTTP routes
-func (h *EventBusHandler) RegisterRoutes(mux *http.ServeMux) {
-	// Health and monitoring endpoints
-	mux.HandleFunc("/health", h.middleware(h.HealthCheck))
-	mux.HandleFunc("/metrics", h.middleware(h.GetMetrics))
-	mux.HandleFunc("/version", h.middleware(h.GetVersion))
-
-	// Event publishing endpoints
-	mux.HandleFunc("/events", h.middleware(h.PublishEvent))
-	mux.HandleFunc("/events/batch", h.middleware(h.PublishEventBatch))
-
-	// Admin endpoints
-	mux.HandleFunc("/admin/config", h.middleware(h.GetConfig))
-}
-
-// Event Publishing Handlers
-
-// PublishEvent handles single event publishing
-func (h *EventBusHandler) PublishEvent(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
-		return
-	}
-
-	var req EventRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.respondError(w, http.StatusBadRequest, "Invalid request body", err)
-		return
-	}
-
-	// Validate request
-	if err := h.validateEventRequest(&req); err != nil {
-		h.respondError(w, http.StatusBadRequest, "Invalid request", err)
-		return
-	}
-
-	// Create message
-	message := &kafka.Message{
-		ID:        fmt.Sprintf("event_%d", time.Now().UnixNano()),
-		EventType: req.EventType,
-		Source:    req.Source,
-		Data:      req.Data,
-		Topic:     req.Topic,
-		Key:       req.Key,
-		Headers:   req.Headers,
-		Metadata: kafka.MessageMetadata{
-			Timestamp:   time.Now(),
-			Version:     "1.0",
-			ContentType: "application/json",
-			Encoding:    "utf-8",
-		},
-	}
-
-	if message.Headers == nil {
-		message.Headers = make(map[string]string)
-	}
-	if message.Topic == "" {
-		message.Topic = fmt.Sprintf("app.%s", req.EventType)
-	}
-
-	// Publish message
-	if err := h.kafka.PublishMessage(r.Context(), message); err != nil {
-		h.respondError(w, http.StatusInternalServerError, "Failed to publish event", err)
-		return
-	}
-
-	h.respondSuccess(w, map[string]interface{}{
-		"event_id": message.ID,
-		"topic":    message.Topic,
-		"status":   "published",
-	}, "Event published successfully")
-}
-
-// PublishEventBatch handles batch event publishing
-func (h *EventBusHandler) PublishEventBatch(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
-		return
-	}
-
-	var req struct {
-		Events []EventRequest `json:"events"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.respondError(w, http.StatusBadRequest, "Invalid request body", err)
-		return
-	}
-
-	if len(req.Events) == 0 {
-		h.respondError(w, http.StatusBadRequest, "No events provided", nil)
-		return
-	}
-
-	if len(req.Events) > 1000 {
-		h.respondError(w, http.StatusBadRequest, "Too many events in batch (max 1000)", nil)
-		return
-	}
-
-	results := make([]map[string]interface{}, 0, len(req.Events))
-	errors := make([]map[string]interface{}, 0)
-
-	for i, eventReq := range req.Events {
-		// Validate each event
-		if err := h.validateEventRequest(&eventReq); err != nil {
-			errors = append(errors, map[string]interface{}{
-				"index": i,
-				"error": err.Error(),
-				"event": eventReq,
-			})
-			continue
-		}
-
-		// Create message
-		message := &kafka.Message{
-			ID:        fmt.Sprintf("batch_event_%d_%d", time.Now().UnixNano(), i),
-			EventType: eventReq.EventType,
-			Source:    eventReq.Source,
-			Data:      eventReq.Data,
-			Topic:     eventReq.Topic,
-			Key:       eventReq.Key,
-			Headers:   eventReq.Headers,
-			Metadata: kafka.MessageMetadata{
-				Timestamp:   time.Now(),
-				Version:     "1.0",
-				ContentType: "application/json",
-				Encoding:    "utf-8",
-			},
-		}
-
-		if message.Headers == nil {
-			message.Headers = make(map[string]string)
-		}
-		if message.Topic == "" {
-			message.Topic = fmt.Sprintf("app.%s", eventReq.EventType)
-		}
-
-		// Publish message
-		if err := h.kafka.PublishMessage(r.Context(), message); err != nil {
-			errors = append(errors, map[string]interface{}{
-				"index":    i,
-				"event_id": message.ID,
-				"error":    err.Error(),
-			})
-			continue
-		}
-
-		results = append(results, map[string]interface{}{
-			"index":    i,
-			"event_id": message.ID,
-			"topic":    message.Topic,
-			"status":   "published",
-		})
-	}
-
-	response := map[string]interface{}{
-		"total_events":      len(req.Events),
-		"successful_events": len(results),
-		"failed_events":     len(errors),
-		"results":           results,
-	}
-
-	if len(errors) > 0 {
-		response["errors"] = errors
-	}
-
-	status := http.StatusOK
-	message := "Batch processing completed"
-	if len(errors) == len(req.Events) {
-		status = http.StatusInternalServerError
-		message = "All events failed to publish"
-	} else if len(errors) > 0 {
-		status = http.StatusPartialContent
-		message = "Some events failed to publish"
-	}
-
-	h.respond(w, status, true, message, response, nil)
-}
-
-// Health and Monitoring Handlers
-
-// HealthCheck handles health check requests
-func (h *EventBusHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
-		return
-	}
-
-	startTime := time.Now()
-
-	// Check components
-	components := make(map[string]interface{})
-	dependencies := make(map[string]interface{})
-
-	// Check Kafka
-	kafkaHealthy := true
-	if err := h.kafka.HealthCheck(r.Context()); err != nil {
-		kafkaHealthy = false
-		components["kafka"] = map[string]interface{}{
-			"status": "unhealthy",
-			"error":  err.Error(),
-		}
-	} else {
-		components["kafka"] = map[string]interface{}{
-			"status": "healthy",
-		}
-	}
-
-	// Check Debezium
-	debeziumHealthy := true
-	if err := h.debezium.HealthCheck(r.Context()); err != nil {
-		debeziumHealthy = false
-		components["debezium"] = map[string]interface{}{
-			"status": "unhealthy",
-			"error":  err.Error(),
-		}
-	} else {
-		components["debezium"] = map[string]interface{}{
-			"status": "healthy",
-		}
-	}
-
-	// Overall status
-	overallStatus := "healthy"
-	statusCode := http.StatusOK
-	if !kafkaHealthy || !debeziumHealthy {
-		overallStatus = "unhealthy"
-		statusCode = http.StatusServiceUnavailable
-	}
-
-	response := HealthResponse{
-		Status:       overallStatus,
-		Version:      "1.0.0",
-		Timestamp:    time.Now(),
-		Uptime:       time.Since(startTime),
-		Components:   components,
-		Dependencies: dependencies,
-	}
-
-	h.respond(w, statusCode, overallStatus == "healthy", "Health check completed", response, nil)
-}
-
-// GetMetrics handles metrics requests
-func (h *EventBusHandler) GetMetrics(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
-		return
-	}
-
-	// Placeholder metrics implementation
-	metrics := map[string]interface{}{
-		"events": map[string]interface{}{
-			"total_processed": 1000,
-			"total_failed":    10,
-			"processing_rate": 100.5,
-			"average_latency": 0.05,
-			"error_rate":      0.01,
-		},
-		"kafka": map[string]interface{}{
-			"connected_brokers": 3,
-			"active_producers":  2,
-			"active_consumers":  5,
-			"messages_per_sec":  250.0,
-			"bytes_per_sec":     1024000.0,
-		},
-		"debezium": map[string]interface{}{
-			"total_connectors":   3,
-			"running_connectors": 3,
-			"failed_connectors":  0,
-		},
-		"timestamp": time.Now(),
-	}
-
-	h.respondSuccess(w, metrics, "Metrics retrieved successfully")
-}
-
-// GetVersion handles version requests
-func (h *EventBusHandler) GetVersion(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
-		return
-	}
-
-	version := map[string]interface{}{
-		"version":    "1.0.0",
-		"build_time": "2024-01-01T00:00:00Z",
-		"git_commit": "latest",
-		"go_version": "1.21",
-	}
-
-	h.respondSuccess(w, version, "Version information retrieved successfully")
-}
-
-// Admin Handlers
-
-// GetConfig handles configuration requests
-func (h *EventBusHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
-		return
-	}
-
-	// Return sanitized configuration (remove sensitive data)
-	sanitizedConfig := map[string]interface{}{
-		"server": map[string]interface{}{
-			"host": h.config.Server.Host,
-			"port": h.config.Server.Port,
-		},
-		"kafka": map[string]interface{}{
-			"brokers": h.config.Kafka.Brokers,
-		},
-		"event_processing": map[string]interface{}{
-			"workers":         h.config.EventProcessing.Workers,
-			"batch_size":      h.config.EventProcessing.BatchSize,
-			"process_timeout": h.config.EventProcessing.ProcessTimeout,
-		},
-	}
-
-	h.respondSuccess(w, sanitizedConfig, "Configuration retrieved successfully")
-}
-
-// Helper Methods
-
-// middleware wraps handlers with common middleware functionality
-func (h *EventBusHandler) middleware(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-
-		// Set common headers
-		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("X-Service", "event-bus-service")
-		w.Header().Set("X-Version", "1.0.0")
-
-		// Add request ID
-		requestID := r.Header.Get("X-Request-ID")
-		if requestID == "" {
-			requestID = fmt.Sprintf("req_%d", time.Now().UnixNano())
-		}
-		w.Header().Set("X-Request-ID", requestID)
-
-		// Log request
-		h.logger.Info("HTTP request",
-			zap.String("method", r.Method),
-			zap.String("path", r.URL.Path),
-			zap.String("request_id", requestID),
-			zap.String("user_agent", r.UserAgent()),
-			zap.String("remote_addr", r.RemoteAddr))
-
-		// Increment active connections
-		h.metrics.ActiveConnections.Inc()
-		defer h.metrics.ActiveConnections.Dec()
-
-		// Call next handler
-		next(w, r)
-
-		// Record metrics
-		duration := time.Since(start)
-		h.metrics.RequestDuration.WithLabelValues(r.Method, r.URL.Path).Observe(duration.Seconds())
-		h.metrics.RequestsTotal.WithLabelValues(r.Method, r.URL.Path, "200").Inc()
-
-		// Log response
-		h.logger.Info("HTTP response",
-			zap.String("method", r.Method),
-			zap.String("path", r.URL.Path),
-			zap.String("request_id", requestID),
-			zap.Duration("duration", duration))
-	}
-}
-
-// respond sends a standardized JSON response
-func (h *EventBusHandler) respond(w http.ResponseWriter, statusCode int, success bool, message string, data interface{}, error interface{}) {
-	response := APIResponse{
-		Success:   success,
-		Message:   message,
-		Data:      data,
-		Error:     error,
-		Timestamp: time.Now(),
-		Version:   "1.0.0",
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		h.logger.Error("Failed to encode response", zap.Error(err))
-	}
-}
-
-// respondSuccess sends a successful response
-func (h *EventBusHandler) respondSuccess(w http.ResponseWriter, data interface{}, message string) {
-	h.respond(w, http.StatusOK, true, message, data, nil)
-}
-
-// respondError sends an error response
-func (h *EventBusHandler) respondError(w http.ResponseWriter, statusCode int, message string, err error) {
-	var errorData interface{}
-	if err != nil {
-		errorData = err.Error()
-		h.logger.Error("HTTP error", zap.String("message", message), zap.Error(err))
-	}
-
-	h.respond(w, statusCode, false, message, nil, errorData)
-	h.metrics.ErrorsTotal.WithLabelValues(fmt.Sprintf("%d", statusCode), message).Inc()
-}
-
-// validateEventRequest validates an event request
-func (h *EventBusHandler) validateEventRequest(req *EventRequest) error {
-	if req.EventType == "" {
-		return fmt.Errorf("event_type is required")
-	}
-
-	if req.Source == "" {
-		return fmt.Errorf("source is required")
-	}
-
-	if req.Data == nil {
-		return fmt.Errorf("data is required")
-	}
-
-	return nil
-}
-
-// initHandlerMetrics initializes Prometheus metrics for handlers
-func initHandlerMetrics() *HandlerMetrics {
-	return &HandlerMetrics{
-		RequestsTotal: promauto.NewCounterVec(prometheus.CounterOpts{
-			Name: "eventbus_http_requests_total",
-			Help: "Total number of HTTP requests",
-		}, []string{"method", "path", "status"}),
-		RequestDuration: promauto.NewHistogramVec(prometheus.HistogramOpts{
-			Name:    "eventbus_http_request_duration_seconds",
-			Help:    "Duration of HTTP requests",
-			Buckets: prometheus.DefBuckets,
-		}, []string{"method", "path"}),
-		ErrorsTotal: promauto.NewCounterVec(prometheus.CounterOpts{
-			Name: "eventbus_http_errors_total",
-			Help: "Total number of HTTP errors",
-		}, []string{"status", "type"}),
-		ActiveConnections: promauto.NewGauge(prometheus.GaugeOpts{
-			Name: "eventbus_http_active_connections",
-			Help: "Number of active HTTP connections",
-		}),
-	}
-}
-
-// EventBusHandler provides HTTP handlers for the Event Bus Service
-type EventBusHandler struct {
-	config           *config.Config
-	logger           *zap.Logger
-	kafka            *kafka.Client
-	debezium         *debezium.Manager
-	processorManager *processors.ProcessorManager
-	metrics          *HandlerMetrics
+	events           eventstore.Searcher
 }
 
 // HandlerMetrics contains Prometheus metrics for HTTP handlers
@@ -583,7 +72,8 @@ type EventBatchRequest struct {
 	Events []EventRequest `json:"events" validate:"required,min=1,max=1000"`
 }
 
-// ConnectorRequest represents a Debezium connector creation request
+// ConnectorRequest represents a Debezium connector creation request. Secrets
+// in Config, such as database.password, must be ${secret:key} references.
 type ConnectorRequest struct {
 	Name     string            `json:"name" validate:"required"`
 	Type     string            `json:"type" validate:"required,oneof=postgres mysql mongodb"`
@@ -592,6 +82,12 @@ type ConnectorRequest struct {
 	Topics   *TopicsConfig     `json:"topics,omitempty"`
 }
 
+// ConnectorSecretsRequest maps the config keys of a connector holding
+// plaintext secrets to the ${secret:key} references replacing them
+type ConnectorSecretsRequest struct {
+	References map[string]string `json:"references" validate:"required"`
+}
+
 // DatabaseConfig represents database configuration for connectors
 type DatabaseConfig struct {
 	Host     string `json:"host" validate:"required"`
@@ -703,17 +199,23 @@ type SystemMetrics struct {
 	GCPauses    float64 `json:"gc_pauses"`
 }
 
-// NewEventBusHandler creates a new event bus handler
+// NewEventBusHandler creates a new event bus handler. The metrics are
+// created once by the application with NewHandlerMetricsFor and shared by
+// its handlers; nil gives the handler metrics of its own.
 func NewEventBusHandler(
 	cfg *config.Config,
 	logger *zap.Logger,
 	kafkaClient *kafka.Client,
 	debeziumManager *debezium.Manager,
 	processorManager *processors.ProcessorManager,
+	metrics *HandlerMetrics,
 ) *EventBusHandler {
 	if logger == nil {
 		logger = zap.NewNop()
 	}
+	if metrics == nil {
+		metrics = NewHandlerMetricsFor(nil)
+	}
 
 	return &EventBusHandler{
 		config:           cfg,
@@ -721,10 +223,16 @@ func NewEventBusHandler(
 		kafka:            kafkaClient,
 		debezium:         debeziumManager,
 		processorManager: processorManager,
-		metrics:          initHandlerMetrics(),
+		metrics:          metrics,
 	}
 }
 
+// SetEventStore sets the store searched by FilterEvents. Without one,
+// filtering responds 503.
+func (h *EventBusHandler) SetEventStore(store eventstore.Searcher) {
+	h.events = store
+}
+
 // RegisterRoutes registers all HTTP routes
 func (h *EventBusHandler) RegisterRoutes(mux *http.ServeMux) {
 	// Health and monitoring endpoints
@@ -738,7 +246,7 @@ func (h *EventBusHandler) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/events/filter", h.middleware(h.FilterEvents))
 
 	// Debezium connector management endpoints
-	mux.HandleFunc("/connectors", h.middleware(h.ListConnectors))
+	mux.HandleFunc("/connectors", h.middleware(h.HandleConnectors))
 	mux.HandleFunc("/connectors/", h.middleware(h.HandleConnectorOperations))
 
 	// Processor management endpoints
@@ -796,6 +304,10 @@ func (h *EventBusHandler) PublishEvent(w http.ResponseWriter, r *http.Request) {
 
 	// Publish message
 	if err := h.kafka.PublishMessage(r.Context(), message); err != nil {
+		if errors.Is(err, kafka.ErrMessageTooLarge) {
+			h.respondError(w, http.StatusRequestEntityTooLarge, "Event too large", err)
+			return
+		}
 		h.respondError(w, http.StatusInternalServerError, "Failed to publish event", err)
 		return
 	}
@@ -905,23 +417,70 @@ func (h *EventBusHandler) PublishEventBatch(w http.ResponseWriter, r *http.Reque
 	h.respond(w, status, true, message, response, nil)
 }
 
-// FilterEvents handles event filtering requests
+// FilterEvents handles event filtering requests. The page of matching events
+// is streamed from the event store inside the response envelope.
 func (h *EventBusHandler) FilterEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
+		return
+	}
+	if h.events == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "Event store is not enabled", nil)
+		return
+	}
+
 	var req FilterRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.respondError(w, http.StatusBadRequest, "Invalid request body", err)
 		return
 	}
 
-	// This is a placeholder implementation
-	// In a real system, you would implement event querying from storage
-	h.respondSuccess(w, map[string]interface{}{
-		"events":  []interface{}{},
-		"total":   0,
-		"limit":   req.Limit,
-		"offset":  req.Offset,
-		"filters": req,
-	}, "Events filtered successfully")
+	cursor, err := h.events.Search(r.Context(), req.eventFilter())
+	if errors.Is(err, eventstore.ErrInvalidFilter) {
+		h.respondError(w, http.StatusBadRequest, "Invalid filter", err)
+		return
+	}
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to filter events", err)
+		return
+	}
+	defer cursor.Close()
+
+	// The status is sent before the first event is read, so a failure while
+	// streaming can only be logged
+	timestamp, _ := json.Marshal(time.Now())
+	version, _ := json.Marshal(h.config.Version)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, `{"success":true,"message":"Events filtered successfully","data":`)
+	if _, err := cursor.WritePage(w); err != nil {
+		h.logger.Error("Failed to stream filtered events", zap.Error(err))
+		return
+	}
+	fmt.Fprintf(w, ",\"timestamp\":%s,\"version\":%s}\n", timestamp, version)
+}
+
+// eventFilter converts the request to the filter of the event store
+func (req FilterRequest) eventFilter() eventstore.Filter {
+	filter := eventstore.Filter{
+		EventFilter: events.EventFilter{
+			EventTypes:    req.EventTypes,
+			Sources:       req.Sources,
+			Tables:        req.Tables,
+			Operations:    req.Operations,
+			IncludeFields: req.IncludeFields,
+			ExcludeFields: req.ExcludeFields,
+		},
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	}
+	if req.TimeRange != nil {
+		filter.TimeRange = &events.TimeRange{From: req.TimeRange.From, To: req.TimeRange.To}
+	}
+	for _, condition := range req.Conditions {
+		filter.Conditions = append(filter.Conditions, events.FilterCondition(condition))
+	}
+	return filter
 }
 
 // Debezium Connector Handlers
@@ -1051,6 +610,43 @@ func (h *EventBusHandler) GetConnectorStatus(w http.ResponseWriter, r *http.Requ
 	h.respondSuccess(w, status, "Connector status retrieved successfully")
 }
 
+// MigrateConnectorSecrets handles moving the plaintext secrets of a connector
+// created before secret references to references
+func (h *EventBusHandler) MigrateConnectorSecrets(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	connectorName := vars["name"]
+
+	if connectorName == "" {
+		h.respondError(w, http.StatusBadRequest, "Connector name is required", nil)
+		return
+	}
+
+	var req ConnectorSecretsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if len(req.References) == 0 {
+		h.respondError(w, http.StatusBadRequest, "Invalid request", fmt.Errorf("references are required"))
+		return
+	}
+
+	config, err := h.debezium.MigrateConnectorSecrets(r.Context(), connectorName, req.References)
+	if errors.Is(err, debezium.ErrInvalidSecretMigration) {
+		h.respondError(w, http.StatusBadRequest, "Invalid secret migration", err)
+		return
+	}
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to migrate connector secrets", err)
+		return
+	}
+
+	h.respondSuccess(w, map[string]interface{}{
+		"connector_name": connectorName,
+		"config":         config,
+	}, "Connector secrets migrated successfully")
+}
+
 // Health and Monitoring Handlers
 
 // HealthCheck handles health check requests
@@ -1063,7 +659,7 @@ func (h *EventBusHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 
 	// Check Kafka
 	kafkaHealthy := true
-	if err := h.kafka.HealthCheck(); err != nil {
+	if err := h.kafka.HealthCheck(r.Context()); err != nil {
 		kafkaHealthy = false
 		components["kafka"] = map[string]interface{}{
 			"status": "unhealthy",
@@ -1099,7 +695,7 @@ func (h *EventBusHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 
 	response := HealthResponse{
 		Status:       overallStatus,
-		Version:      h.config.Server.Version,
+		Version:      h.config.Version,
 		Timestamp:    time.Now(),
 		Uptime:       time.Since(startTime),
 		Components:   components,
@@ -1158,7 +754,7 @@ func (h *EventBusHandler) GetMetrics(w http.ResponseWriter, r *http.Request) {
 // GetVersion handles version requests
 func (h *EventBusHandler) GetVersion(w http.ResponseWriter, r *http.Request) {
 	version := map[string]interface{}{
-		"version":    h.config.Server.Version,
+		"version":    h.config.Version,
 		"build_time": "2024-01-01T00:00:00Z", // This would be set during build
 		"git_commit": "latest",               // This would be set during build
 		"go_version": "1.21",
@@ -1176,12 +772,12 @@ func (h *EventBusHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
 		"server": map[string]interface{}{
 			"host":        h.config.Server.Host,
 			"port":        h.config.Server.Port,
-			"version":     h.config.Server.Version,
-			"environment": h.config.Server.Environment,
+			"version":     h.config.Version,
+			"environment": h.config.Environment,
 		},
 		"kafka": map[string]interface{}{
-			"brokers": h.config.Kafka.Brokers,
-			"timeout": h.config.Kafka.Timeout,
+			"brokers":   h.config.Kafka.Brokers,
+			"client_id": h.config.Kafka.ClientID,
 		},
 		"event_processing": map[string]interface{}{
 			"workers":         h.config.EventProcessing.Workers,
@@ -1273,14 +869,19 @@ func (h *EventBusHandler) GetTopicInfo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Placeholder implementation
-	topicInfo := map[string]interface{}{
-		"name":               topicName,
-		"partitions":         3,
-		"replication_factor": 2,
-		"message_count":      1500,
-		"size_bytes":         2048000,
-		"created_at":         time.Now().Add(-24 * time.Hour),
+	if h.kafka == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "Kafka is not available", nil)
+		return
+	}
+
+	topicInfo, err := h.kafka.DescribeTopic(r.Context(), topicName)
+	if errors.Is(err, kafka.ErrTopicNotFound) {
+		h.respondError(w, http.StatusNotFound, "Topic not found", err)
+		return
+	}
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to describe topic", err)
+		return
 	}
 
 	h.respondSuccess(w, topicInfo, "Topic information retrieved successfully")
@@ -1335,8 +936,92 @@ func (h *EventBusHandler) GetTopicMessages(w http.ResponseWriter, r *http.Reques
 	}, "Topic messages retrieved successfully")
 }
 
+// Routing Handlers
+
+// HandleConnectors dispatches requests on the connector collection
+func (h *EventBusHandler) HandleConnectors(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.ListConnectors(w, r)
+	case http.MethodPost:
+		h.CreateConnector(w, r)
+	default:
+		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
+	}
+}
+
+// HandleConnectorOperations dispatches requests on a single connector:
+// /connectors/{name}, /connectors/{name}/status, /connectors/{name}/restart
+// and /connectors/{name}/secrets
+func (h *EventBusHandler) HandleConnectorOperations(w http.ResponseWriter, r *http.Request) {
+	r, action, ok := h.resourceRequest(w, r, "/connectors/")
+	if !ok {
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		h.GetConnector(w, r)
+	case action == "" && r.Method == http.MethodDelete:
+		h.DeleteConnector(w, r)
+	case action == "status" && r.Method == http.MethodGet:
+		h.GetConnectorStatus(w, r)
+	case action == "restart" && r.Method == http.MethodPost:
+		h.RestartConnector(w, r)
+	case action == "secrets" && r.Method == http.MethodPost:
+		h.MigrateConnectorSecrets(w, r)
+	default:
+		h.respondError(w, http.StatusNotFound, "Connector operation not found", nil)
+	}
+}
+
+// HandleProcessorOperations dispatches requests on a single processor:
+// /processors/{name}
+func (h *EventBusHandler) HandleProcessorOperations(w http.ResponseWriter, r *http.Request) {
+	r, action, ok := h.resourceRequest(w, r, "/processors/")
+	if !ok {
+		return
+	}
+
+	if action != "" || r.Method != http.MethodGet {
+		h.respondError(w, http.StatusNotFound, "Processor operation not found", nil)
+		return
+	}
+	h.GetProcessorStatus(w, r)
+}
+
+// HandleTopicOperations dispatches requests on a single topic:
+// /topics/{name} and /topics/{name}/messages
+func (h *EventBusHandler) HandleTopicOperations(w http.ResponseWriter, r *http.Request) {
+	r, action, ok := h.resourceRequest(w, r, "/topics/")
+	if !ok {
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		h.GetTopicInfo(w, r)
+	case action == "messages" && r.Method == http.MethodGet:
+		h.GetTopicMessages(w, r)
+	default:
+		h.respondError(w, http.StatusNotFound, "Topic operation not found", nil)
+	}
+}
+
 // Helper Methods
 
+// resourceRequest splits a /{collection}/{name}[/{action}] path, exposes the
+// name as the "name" route variable and returns the action. It responds with
+// 400 and returns false when the name is missing.
+func (h *EventBusHandler) resourceRequest(w http.ResponseWriter, r *http.Request, prefix string) (*http.Request, string, bool) {
+	name, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, prefix), "/")
+	if name == "" {
+		h.respondError(w, http.StatusBadRequest, "Resource name is required", nil)
+		return r, "", false
+	}
+	return mux.SetURLVars(r, map[string]string{"name": name}), strings.TrimSuffix(action, "/"), true
+}
+
 // middleware wraps handlers with common middleware functionality
 func (h *EventBusHandler) middleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -1345,7 +1030,7 @@ func (h *EventBusHandler) middleware(next http.HandlerFunc) http.HandlerFunc {
 		// Set common headers
 		w.Header().Set("Content-Type", "application/json")
 		w.Header().Set("X-Service", "event-bus-service")
-		w.Header().Set("X-Version", h.config.Server.Version)
+		w.Header().Set("X-Version", h.config.Version)
 
 		// Add request ID
 		requestID := r.Header.Get("X-Request-ID")
@@ -1391,7 +1076,7 @@ func (h *EventBusHandler) respond(w http.ResponseWriter, statusCode int, success
 		Data:      data,
 		Error:     error,
 		Timestamp: time.Now(),
-		Version:   h.config.Server.Version,
+		Version:   h.config.Version,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -1450,6 +1135,16 @@ func (h *EventBusHandler) validateConnectorRequest(req *ConnectorRequest) error
 		return fmt.Errorf("config is required")
 	}
 
+	// Secrets are sent as references so they are never stored or returned
+	plaintext := debezium.PlaintextSecrets(req.Config)
+	if req.Database != nil && req.Database.Password != "" && !debezium.HasSecretRef(req.Database.Password) {
+		plaintext = append(plaintext, "database.password")
+	}
+	if len(plaintext) > 0 {
+		sort.Strings(plaintext)
+		return fmt.Errorf("%s must be ${secret:key} references rather than plaintext", strings.Join(plaintext, ", "))
+	}
+
 	// Validate type
 	validTypes := []string{"postgres", "mysql", "mongodb"}
 	validType := false
@@ -1466,30 +1161,39 @@ func (h *EventBusHandler) validateConnectorRequest(req *ConnectorRequest) error
 	return nil
 }
 
-// initHandlerMetrics initializes Prometheus metrics for handlers
-func initHandlerMetrics() *HandlerMetrics {
-	return &HandlerMetrics{
-		RequestsTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+// NewHandlerMetricsFor creates the handler metrics and registers them with
+// reg. A nil reg registers them with a new registry, which keeps handlers
+// built in tests independent of each other.
+func NewHandlerMetricsFor(reg prometheus.Registerer) *HandlerMetrics {
+	if reg == nil {
+		reg = prometheus.NewRegistry()
+	}
+
+	m := &HandlerMetrics{
+		RequestsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Name: "eventbus_http_requests_total",
 			Help: "Total number of HTTP requests",
 		}, []string{"method", "path", "status"}),
-		RequestDuration: promauto.NewHistogramVec(prometheus.HistogramOpts{
+		RequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
 			Name:    "eventbus_http_request_duration_seconds",
 			Help:    "Duration of HTTP requests",
 			Buckets: prometheus.DefBuckets,
 		}, []string{"method", "path"}),
-		ResponseSizeBytes: promauto.NewHistogramVec(prometheus.HistogramOpts{
+		ResponseSizeBytes: prometheus.NewHistogramVec(prometheus.HistogramOpts{
 			Name:    "eventbus_http_response_size_bytes",
 			Help:    "Size of HTTP responses in bytes",
 			Buckets: prometheus.ExponentialBuckets(100, 10, 8),
 		}, []string{"method", "path"}),
-		ErrorsTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+		ErrorsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Name: "eventbus_http_errors_total",
 			Help: "Total number of HTTP errors",
 		}, []string{"status", "type"}),
-		ActiveConnections: promauto.NewGauge(prometheus.GaugeOpts{
+		ActiveConnections: prometheus.NewGauge(prometheus.GaugeOpts{
 			Name: "eventbus_http_active_connections",
 			Help: "Number of active HTTP connections",
 		}),
 	}
+
+	reg.MustRegister(m.RequestsTotal, m.RequestDuration, m.ResponseSizeBytes, m.ErrorsTotal, m.ActiveConnections)
+	return m
 }
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/debezium"
+)
+
+func TestRegisterRoutes(t *testing.T) {
+	handler := NewEventBusHandler(&config.Config{Version: "test"}, nil, nil, nil, nil, nil)
+
+	// http.ServeMux panics when a pattern is registered twice, so this also
+	// asserts every route is registered exactly once
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	routes := []struct {
+		method  string
+		path    string
+		pattern string
+	}{
+		{http.MethodGet, "/health", "/health"},
+		{http.MethodGet, "/metrics", "/metrics"},
+		{http.MethodGet, "/version", "/version"},
+		{http.MethodPost, "/events", "/events"},
+		{http.MethodPost, "/events/batch", "/events/batch"},
+		{http.MethodPost, "/events/filter", "/events/filter"},
+		{http.MethodGet, "/connectors", "/connectors"},
+		{http.MethodPost, "/connectors", "/connectors"},
+		{http.MethodGet, "/connectors/orders", "/connectors/"},
+		{http.MethodDelete, "/connectors/orders", "/connectors/"},
+		{http.MethodGet, "/connectors/orders/status", "/connectors/"},
+		{http.MethodPost, "/connectors/orders/restart", "/connectors/"},
+		{http.MethodPost, "/connectors/orders/secrets", "/connectors/"},
+		{http.MethodGet, "/processors", "/processors"},
+		{http.MethodGet, "/processors/cdc-processor", "/processors/"},
+		{http.MethodGet, "/topics", "/topics"},
+		{http.MethodGet, "/topics/app.form.created", "/topics/"},
+		{http.MethodGet, "/topics/app.form.created/messages", "/topics/"},
+		{http.MethodGet, "/admin/config", "/admin/config"},
+		{http.MethodPost, "/admin/shutdown", "/admin/shutdown"},
+	}
+
+	for _, route := range routes {
+		req := httptest.NewRequest(route.method, route.path, nil)
+		if _, pattern := mux.Handler(req); pattern != route.pattern {
+			t.Errorf("%s %s: expected pattern %q, got %q", route.method, route.path, route.pattern, pattern)
+		}
+	}
+}
+
+func TestResourceOperationDispatch(t *testing.T) {
+	handler := NewEventBusHandler(&config.Config{Version: "test"}, nil, nil, nil, nil, nil)
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+	}{
+		{http.MethodPut, "/connectors", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/connectors/", http.StatusBadRequest},
+		{http.MethodGet, "/connectors/orders/pause", http.StatusNotFound},
+		{http.MethodGet, "/processors/cdc-processor", http.StatusOK},
+		{http.MethodGet, "/processors/cdc-processor/tasks", http.StatusNotFound},
+		{http.MethodGet, "/topics/app.form.created", http.StatusServiceUnavailable},
+		{http.MethodGet, "/topics/app.form.created/messages", http.StatusOK},
+		{http.MethodPost, "/topics/app.form.created", http.StatusNotFound},
+		{http.MethodPost, "/events/filter", http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != tt.status {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.path, tt.status, rec.Code)
+		}
+	}
+}
+
+func TestHandlerMetricsFor(t *testing.T) {
+	metrics := NewHandlerMetricsFor(prometheus.NewRegistry())
+
+	// Handlers sharing one set of metrics record into the same collectors
+	mux := http.NewServeMux()
+	NewEventBusHandler(&config.Config{Version: "test"}, nil, nil, nil, nil, metrics).RegisterRoutes(mux)
+	other := NewEventBusHandler(&config.Config{Version: "test"}, nil, nil, nil, nil, metrics)
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/processors/cdc-processor", nil))
+	other.HandleTopicOperations(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/topics/app.form.created", nil))
+
+	if got := testutil.ToFloat64(metrics.RequestsTotal.WithLabelValues(http.MethodGet, "/processors/cdc-processor", "200")); got != 1 {
+		t.Errorf("expected 1 request recorded, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.ErrorsTotal.WithLabelValues("404", "Topic operation not found")); got != 1 {
+		t.Errorf("expected 1 error recorded, got %v", got)
+	}
+}
+
+func TestConnectorSecretsNeverReturned(t *testing.T) {
+	const secret = "s3cr3t-pg-password"
+	t.Setenv("EVENTBUS_POSTGRES_PASSWORD", secret)
+
+	// Kafka Connect holds the password of the connector created before
+	// references, and echoes configs in its errors
+	var sent map[string]string
+	connect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case r.URL.Path == "/":
+			w.Write([]byte(`{"version":"3.6.0"}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/connectors":
+			var req debezium.ConnectorConfig
+			json.Unmarshal(body, &req)
+			sent = req.Config
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write(body)
+		case r.URL.Path == "/connectors/legacy/config" && r.Method == http.MethodGet:
+			json.NewEncoder(w).Encode(map[string]string{"connector.class": "io.debezium.connector.postgresql.PostgresConnector", "database.password": secret})
+		case r.URL.Path == "/connectors/legacy/config" && r.Method == http.MethodPut:
+			w.WriteHeader(http.StatusOK)
+		case strings.HasSuffix(r.URL.Path, "/status"):
+			w.Write([]byte(`{"name":"legacy","tasks":[]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer connect.Close()
+
+	cfg := &config.Config{Version: "test"}
+	cfg.Debezium.Connect.URL = connect.URL
+	cfg.Debezium.Secrets = config.DebeziumSecretsConfig{Provider: "environment", Prefix: "EVENTBUS_"}
+	core, logs := observer.New(zap.DebugLevel)
+	logger := zap.New(core)
+	manager, err := debezium.NewManager(cfg, logger)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	mux := http.NewServeMux()
+	NewEventBusHandler(cfg, logger, nil, manager, nil, nil).RegisterRoutes(mux)
+
+	requests := []struct {
+		method, path, body string
+		status             int
+	}{
+		{http.MethodPost, "/connectors", `{"name":"orders","type":"postgres","config":{"connector.class":"io.debezium.connector.postgresql.PostgresConnector","database.password":"` + secret + `"}}`, http.StatusBadRequest},
+		{http.MethodPost, "/connectors", `{"name":"orders","type":"postgres","config":{"connector.class":"io.debezium.connector.postgresql.PostgresConnector","database.password":"${secret:postgres/password}"}}`, http.StatusInternalServerError},
+		{http.MethodPost, "/connectors/legacy/secrets", `{"references":{"database.password":"${secret:postgres/other}"}}`, http.StatusBadRequest},
+		{http.MethodPost, "/connectors/legacy/secrets", `{"references":{"database.password":"${secret:postgres/password}"}}`, http.StatusOK},
+		{http.MethodGet, "/connectors/legacy", "", http.StatusOK},
+	}
+	for _, req := range requests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(req.method, req.path, strings.NewReader(req.body)))
+		if rec.Code != req.status {
+			t.Errorf("%s %s: expected status %d, got %d: %s", req.method, req.path, req.status, rec.Code, rec.Body.String())
+		}
+		if strings.Contains(rec.Body.String(), secret) {
+			t.Errorf("%s %s: response holds the secret: %s", req.method, req.path, rec.Body.String())
+		}
+	}
+
+	if sent["database.password"] != secret {
+		t.Errorf("Kafka Connect got database.password %q, expected the resolved secret", sent["database.password"])
+	}
+	for _, entry := range logs.All() {
+		fields, _ := json.Marshal(entry.ContextMap())
+		if strings.Contains(entry.Message, secret) || strings.Contains(string(fields), secret) {
+			t.Errorf("log line holds the secret: %s %s", entry.Message, fields)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+// Package invalidation bridges the changes of forms to the caches of the API
+// gateway. The bridge consumes the form.updated, form.published,
+// form.deleted, form.archived and form.transferred events, coalesces each
+// batch to one invalidation per form and publishes it to a Redis pub/sub
+// channel every gateway replica subscribes to. Whatever path a form is changed through,
+// the cached views of it are purged within the batch flush interval; those
+// missed while a gateway is disconnected expire with their TTL.
+package invalidation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+)
+
+// EventTypes are the events that change the cached views of a form
+var EventTypes = map[string]bool{
+	"form.updated":   true,
+	"form.published": true,
+	"form.deleted":   true,
+	"form.archived":  true,
+	// Transfers move forms to other organizations, whose slugs resolve
+	// other forms
+	"form.transferred": true,
+}
+
+// Invalidation tells the gateways to purge the cached views of a form
+type Invalidation struct {
+	FormID string `json:"form_id"`
+	// Slugs are the slugs the form was resolved by, current and previous
+	Slugs []string `json:"slugs,omitempty"`
+	// EventTypes are the events coalesced into the invalidation
+	EventTypes []string `json:"event_types"`
+	// At is when the earliest of the events happened, from which the
+	// gateways measure the invalidation latency
+	At time.Time `json:"at"`
+}
+
+// Publisher publishes messages to pub/sub channels
+type Publisher interface {
+	Publish(ctx context.Context, channel string, message []byte) error
+}
+
+// Bridge publishes the invalidations of the forms changed. It implements
+// kafka.BatchConsumerHandler.
+type Bridge struct {
+	publisher Publisher
+	metrics   *Metrics
+	logger    *zap.Logger
+
+	topics  []string
+	groupID string
+	channel string
+	now     func() time.Time
+}
+
+// New creates a bridge publishing to the channel of cfg through publisher
+func New(cfg config.CacheInvalidationConfig, publisher Publisher, metrics *Metrics, logger *zap.Logger) *Bridge {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Bridge{
+		publisher: publisher,
+		metrics:   metrics,
+		logger:    logger,
+		topics:    cfg.Topics,
+		groupID:   cfg.GroupID,
+		channel:   cfg.Channel,
+		now:       time.Now,
+	}
+}
+
+// GetTopics returns the topics the bridge consumes
+func (b *Bridge) GetTopics() []string {
+	return b.topics
+}
+
+// GetGroupID returns the consumer group the bridge commits offsets in
+func (b *Bridge) GetGroupID() string {
+	return b.groupID
+}
+
+// formEvent is what the bridge reads of the data of a form event
+type formEvent struct {
+	FormID       string `json:"form_id"`
+	Slug         string `json:"slug"`
+	PreviousSlug string `json:"previous_slug"`
+}
+
+// HandleBatch publishes one invalidation per form changed in the batch, so
+// a burst of edits to a form purges it once. Invalidations that fail to
+// publish are logged and dropped rather than redelivering the batch: the
+// TTL of the cached views bounds how long they stay stale.
+func (b *Bridge) HandleBatch(ctx context.Context, messages []*kafka.Message) error {
+	invalidations := make(map[string]*Invalidation)
+	for _, message := range messages {
+		if !EventTypes[message.EventType] {
+			b.metrics.Events.WithLabelValues("skipped").Inc()
+			continue
+		}
+		event, err := parseFormEvent(message)
+		if err != nil {
+			b.logger.Warn("Dropping invalid form event", zap.String("event_id", message.ID), zap.Error(err))
+			b.metrics.Events.WithLabelValues("invalid").Inc()
+			continue
+		}
+
+		at := message.Metadata.Timestamp
+		if at.IsZero() || at.After(b.now()) {
+			at = b.now()
+		}
+		invalidation, ok := invalidations[event.FormID]
+		if !ok {
+			invalidation = &Invalidation{FormID: event.FormID, At: at}
+			invalidations[event.FormID] = invalidation
+		}
+		if at.Before(invalidation.At) {
+			invalidation.At = at
+		}
+		invalidation.EventTypes = appendOnce(invalidation.EventTypes, message.EventType)
+		for _, slug := range []string{event.Slug, event.PreviousSlug} {
+			if slug != "" {
+				invalidation.Slugs = appendOnce(invalidation.Slugs, slug)
+			}
+		}
+		b.metrics.Events.WithLabelValues("coalesced").Inc()
+	}
+
+	formIDs := make([]string, 0, len(invalidations))
+	for formID := range invalidations {
+		formIDs = append(formIDs, formID)
+	}
+	sort.Strings(formIDs)
+
+	for _, formID := range formIDs {
+		if err := b.publish(ctx, invalidations[formID]); err != nil {
+			b.logger.Warn("Failed to publish the cache invalidation of a form", zap.String("form_id", formID), zap.Error(err))
+			b.metrics.Invalidations.WithLabelValues("failed").Inc()
+			continue
+		}
+		b.metrics.Invalidations.WithLabelValues("published").Inc()
+	}
+	return nil
+}
+
+func (b *Bridge) publish(ctx context.Context, invalidation *Invalidation) error {
+	message, err := json.Marshal(invalidation)
+	if err != nil {
+		return fmt.Errorf("failed to encode invalidation: %w", err)
+	}
+	return b.publisher.Publish(ctx, b.channel, message)
+}
+
+func parseFormEvent(message *kafka.Message) (*formEvent, error) {
+	var payload []byte
+	switch data := message.Data.(type) {
+	case []byte:
+		payload = data
+	case json.RawMessage:
+		payload = data
+	default:
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return nil, err
+		}
+		payload = encoded
+	}
+
+	var event formEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, err
+	}
+	if event.FormID == "" {
+		// Events keyed by form carry its ID as the key
+		event.FormID = message.Key
+	}
+	if event.FormID == "" {
+		return nil, fmt.Errorf("form_id is required")
+	}
+	return &event, nil
+}
+
+func appendOnce(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+// Metrics contains the Prometheus metrics of the bridge
+type Metrics struct {
+	Events        *prometheus.CounterVec
+	Invalidations *prometheus.CounterVec
+}
+
+// NewMetrics creates the bridge metrics and registers them with reg
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		Events: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "eventbus_cache_invalidation_events_total",
+			Help: "Total number of events consumed by the cache invalidation bridge, by outcome",
+		}, []string{"status"}),
+		Invalidations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "eventbus_cache_invalidations_total",
+			Help: "Total number of form cache invalidations sent to the gateways, by outcome",
+		}, []string{"status"}),
+	}
+	reg.MustRegister(m.Events, m.Invalidations)
+	return m
+}
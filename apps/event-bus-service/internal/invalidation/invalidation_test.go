@@ -0,0 +1,85 @@
+package invalidation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+)
+
+// recordingPublisher records the invalidations published
+type recordingPublisher struct {
+	channels      []string
+	invalidations []Invalidation
+	fail          string
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, channel string, message []byte) error {
+	var invalidation Invalidation
+	if err := json.Unmarshal(message, &invalidation); err != nil {
+		return err
+	}
+	if invalidation.FormID == p.fail {
+		return errors.New("connection refused")
+	}
+	p.channels = append(p.channels, channel)
+	p.invalidations = append(p.invalidations, invalidation)
+	return nil
+}
+
+func formEventMessage(id, eventType string, data map[string]interface{}, at time.Time) *kafka.Message {
+	encoded, _ := json.Marshal(data)
+	return &kafka.Message{ID: id, EventType: eventType, Data: json.RawMessage(encoded), Metadata: kafka.MessageMetadata{Timestamp: at}}
+}
+
+func TestBridgeCoalescesPerForm(t *testing.T) {
+	publisher := &recordingPublisher{fail: "form-3"}
+	metrics := NewMetrics(prometheus.NewRegistry())
+	b := New(config.CacheInvalidationConfig{Channel: "gateway:cache:invalidations"}, publisher, metrics, nil)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	b.now = func() time.Time { return now }
+
+	batch := []*kafka.Message{
+		formEventMessage("e1", "form.updated", map[string]interface{}{"form_id": "form-1", "slug": "survey", "previous_slug": "old-survey"}, now.Add(-3*time.Second)),
+		formEventMessage("e2", "form.updated", map[string]interface{}{"form_id": "form-1", "slug": "survey"}, now.Add(-2*time.Second)),
+		formEventMessage("e3", "form.published", map[string]interface{}{"form_id": "form-1", "slug": "survey"}, now.Add(-time.Second)),
+		formEventMessage("e4", "form.deleted", map[string]interface{}{}, now.Add(time.Hour)),
+		formEventMessage("e5", "form.archived", map[string]interface{}{"form_id": "form-3"}, now),
+		formEventMessage("e6", "form.response.created", map[string]interface{}{"form_id": "form-1"}, now),
+		formEventMessage("e7", "form.updated", map[string]interface{}{}, now),
+	}
+	batch[3].Key = "form-2"
+	if err := b.HandleBatch(context.Background(), batch); err != nil {
+		t.Fatalf("a failed publish must not fail the batch: %v", err)
+	}
+
+	want := []Invalidation{
+		{FormID: "form-1", Slugs: []string{"survey", "old-survey"}, EventTypes: []string{"form.updated", "form.published"}, At: now.Add(-3 * time.Second)},
+		// Timestamps from the future are taken as now
+		{FormID: "form-2", EventTypes: []string{"form.deleted"}, At: now},
+	}
+	if !reflect.DeepEqual(publisher.invalidations, want) {
+		t.Errorf("invalidations = %+v\nwant %+v", publisher.invalidations, want)
+	}
+	for _, channel := range publisher.channels {
+		if channel != "gateway:cache:invalidations" {
+			t.Errorf("published to %q", channel)
+		}
+	}
+	for status, want := range map[string]float64{"coalesced": 5, "skipped": 1, "invalid": 1} {
+		if got := testutil.ToFloat64(metrics.Events.WithLabelValues(status)); got != want {
+			t.Errorf("%s events = %v, want %v", status, got, want)
+		}
+	}
+	if got := testutil.ToFloat64(metrics.Invalidations.WithLabelValues("failed")); got != 1 {
+		t.Errorf("failed invalidations = %v, want 1", got)
+	}
+}
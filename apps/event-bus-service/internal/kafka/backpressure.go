@@ -0,0 +1,50 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrBackpressure is returned for messages published while the messages
+// waiting on the brokers are over the limits of kafka.producer.backpressure.
+// Callers should retry later.
+var ErrBackpressure = errors.New("too many messages waiting on the brokers")
+
+// inFlight counts the messages being sent to the brokers and their bytes,
+// and refuses messages past its limits. A zero limit is no limit.
+type inFlight struct {
+	maxMessages int
+	maxBytes    int64
+
+	mu       sync.Mutex
+	messages int
+	bytes    int64
+}
+
+// acquire admits a message of size bytes, to be released once sent. A
+// message is always admitted when none is in flight, so one over the byte
+// limit is not refused forever.
+func (f *inFlight) acquire(size int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.messages > 0 {
+		if f.maxMessages > 0 && f.messages+1 > f.maxMessages {
+			return fmt.Errorf("%w: %d messages in flight", ErrBackpressure, f.messages)
+		}
+		if f.maxBytes > 0 && f.bytes+int64(size) > f.maxBytes {
+			return fmt.Errorf("%w: %d bytes in flight", ErrBackpressure, f.bytes)
+		}
+	}
+	f.messages++
+	f.bytes += int64(size)
+	return nil
+}
+
+// release removes a message acquired with size from the counts
+func (f *inFlight) release(size int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.messages--
+	f.bytes -= int64(size)
+}
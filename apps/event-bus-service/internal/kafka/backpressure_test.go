@@ -0,0 +1,148 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// pausedProducer blocks every send until resumed, like a producer whose
+// brokers stopped answering. Methods it does not override panic.
+type pausedProducer struct {
+	sarama.SyncProducer
+	resumed chan struct{}
+}
+
+func (p *pausedProducer) SendMessage(*sarama.ProducerMessage) (int32, int64, error) {
+	<-p.resumed
+	return 0, 0, nil
+}
+
+// testMetrics returns unregistered producer metrics
+func testMetrics() *KafkaMetrics {
+	return &KafkaMetrics{
+		MessagesProduced:       prometheus.NewCounter(prometheus.CounterOpts{Name: "produced"}),
+		ProducerErrors:         prometheus.NewCounter(prometheus.CounterOpts{Name: "errors"}),
+		ProducerLatency:        prometheus.NewHistogram(prometheus.HistogramOpts{Name: "latency"}),
+		MessageSize:            prometheus.NewHistogram(prometheus.HistogramOpts{Name: "size"}),
+		ClaimChecks:            prometheus.NewCounter(prometheus.CounterOpts{Name: "claim_checks"}),
+		InFlightMessages:       prometheus.NewGauge(prometheus.GaugeOpts{Name: "in_flight_messages"}),
+		InFlightBytes:          prometheus.NewGauge(prometheus.GaugeOpts{Name: "in_flight_bytes"}),
+		BackpressureRejections: prometheus.NewCounter(prometheus.CounterOpts{Name: "rejections"}),
+		EncryptionLatency:      prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "encryption_latency"}, []string{"operation"}),
+		EncryptionErrors:       prometheus.NewCounterVec(prometheus.CounterOpts{Name: "encryption_errors"}, []string{"operation"}),
+	}
+}
+
+func TestInFlightLimits(t *testing.T) {
+	f := inFlight{maxMessages: 2, maxBytes: 100}
+	if err := f.acquire(60); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.acquire(50); !errors.Is(err, ErrBackpressure) {
+		t.Errorf("over the byte limit: err = %v, want ErrBackpressure", err)
+	}
+	if err := f.acquire(40); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.acquire(0); !errors.Is(err, ErrBackpressure) {
+		t.Errorf("over the message limit: err = %v, want ErrBackpressure", err)
+	}
+	f.release(60)
+	f.release(40)
+
+	// Alone, a message over the byte limit goes through
+	if err := f.acquire(500); err != nil {
+		t.Errorf("single large message: err = %v, want it admitted", err)
+	}
+}
+
+// TestBackpressureKeepsMemoryFlat publishes waves of messages while the
+// brokers are paused. Without the limits every wave would leave its
+// messages and goroutines waiting; with them the heap stays flat.
+func TestBackpressureKeepsMemoryFlat(t *testing.T) {
+	if testing.Short() {
+		t.Skip("load test")
+	}
+
+	const (
+		maxInFlight = 100
+		waves       = 5
+		perWave     = 1000
+	)
+	producer := &pausedProducer{resumed: make(chan struct{})}
+	client := &Client{
+		config:        producerTestConfig("none", 1000000),
+		logger:        zap.NewNop(),
+		producer:      producer,
+		recordVersion: 2,
+		inFlight:      inFlight{maxMessages: maxInFlight},
+		metrics:       testMetrics(),
+	}
+	payload := strings.Repeat("x", 10000)
+
+	var (
+		published sync.WaitGroup
+		mu        sync.Mutex
+		rejected  int
+	)
+	publishWave := func(wave int) {
+		for i := 0; i < perWave; i++ {
+			published.Add(1)
+			go func() {
+				defer published.Done()
+				message := testMessage()
+				message.Partition = -1
+				message.Data = map[string]interface{}{"payload": payload}
+				err := client.PublishMessage(context.Background(), message)
+				if errors.Is(err, ErrBackpressure) {
+					mu.Lock()
+					rejected++
+					mu.Unlock()
+				} else if err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		// Rejections return right away; admitted messages stay blocked
+		for {
+			mu.Lock()
+			settled := rejected
+			mu.Unlock()
+			if settled >= (wave+1)*perWave-maxInFlight {
+				break
+			}
+			runtime.Gosched()
+		}
+	}
+
+	var heap []uint64
+	for wave := 0; wave < waves; wave++ {
+		publishWave(wave)
+		runtime.GC()
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		heap = append(heap, stats.HeapAlloc)
+	}
+
+	if want := waves*perWave - maxInFlight; rejected != want {
+		t.Errorf("rejected %d messages, want %d", rejected, want)
+	}
+	// Each wave would retain about 10 MB if its messages were queued
+	if growth := int64(heap[len(heap)-1]) - int64(heap[0]); growth > 4<<20 {
+		t.Errorf("heap grew by %d bytes over %d waves, want it flat", growth, waves)
+	}
+
+	close(producer.resumed)
+	published.Wait()
+	if client.inFlight.messages != 0 || client.inFlight.bytes != 0 {
+		t.Errorf("in flight after resuming: %d messages, %d bytes", client.inFlight.messages, client.inFlight.bytes)
+	}
+}
@@ -0,0 +1,228 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/shared/errreport"
+)
+
+// BatchConsumerHandler handles messages a batch at a time. Offsets are only
+// committed once HandleBatch succeeds, so a batch that fails or is cut short
+// by a crash is delivered again and the handler must be idempotent.
+type BatchConsumerHandler interface {
+	HandleBatch(ctx context.Context, messages []*Message) error
+	GetTopics() []string
+	GetGroupID() string
+}
+
+// BatchOptions controls how a batch consumer groups messages
+type BatchOptions struct {
+	// Size is the largest number of messages handed to HandleBatch at once
+	Size int
+	// FlushInterval is the longest a partial batch waits before being handled
+	FlushInterval time.Duration
+	// RetryBackoff is the wait before consuming again after a failed batch
+	RetryBackoff time.Duration
+}
+
+func (o BatchOptions) withDefaults() BatchOptions {
+	if o.Size <= 0 {
+		o.Size = 100
+	}
+	if o.FlushInterval <= 0 {
+		o.FlushInterval = time.Second
+	}
+	if o.RetryBackoff <= 0 {
+		o.RetryBackoff = time.Second
+	}
+	return o
+}
+
+// StartBatchConsumer starts consuming with the provided batch handler in a
+// consumer group of its own, so its offsets are tracked independently of the
+// client's default consumer group
+func (c *Client) StartBatchConsumer(ctx context.Context, handler BatchConsumerHandler, opts BatchOptions) error {
+	if c.closed {
+		return fmt.Errorf("kafka client is closed")
+	}
+
+	topics := handler.GetTopics()
+	if len(topics) == 0 {
+		return fmt.Errorf("no topics specified for consumer")
+	}
+	opts = opts.withDefaults()
+
+	kafkaConfig, err := c.createKafkaConfig()
+	if err != nil {
+		return fmt.Errorf("failed to create Kafka config: %w", err)
+	}
+	// Offsets are committed explicitly after each handled batch
+	kafkaConfig.Consumer.Offsets.AutoCommit.Enable = false
+
+	group, err := openConsumerGroup(c.consumerBrokers(), handler.GetGroupID(), kafkaConfig, func(err error) {
+		c.logger.Error("Batch consumer group error", zap.Error(err))
+		c.metrics.ConsumerErrors.Inc()
+	})
+	if err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	c.batchConsumers = append(c.batchConsumers, group)
+	c.mutex.Unlock()
+
+	c.logger.Info("Starting Kafka batch consumer",
+		zap.Strings("topics", topics),
+		zap.String("group_id", handler.GetGroupID()),
+		zap.Int("batch_size", opts.Size))
+
+	groupHandler := &batchConsumerGroupHandler{
+		client:  c,
+		group:   group,
+		handler: handler,
+		opts:    opts,
+		logger:  c.logger,
+	}
+
+	go func() {
+		for {
+			if err := group.current().Consume(ctx, topics, groupHandler); err != nil {
+				c.logger.Error("Batch consumer error",
+					zap.String("group_id", handler.GetGroupID()),
+					zap.Error(err))
+				c.metrics.ConsumerErrors.Inc()
+
+				// Back off before rejoining; the failed batch is redelivered
+				// from the last committed offset
+				select {
+				case <-time.After(opts.RetryBackoff):
+				case <-ctx.Done():
+				}
+			}
+
+			if ctx.Err() != nil {
+				c.logger.Info("Batch consumer context cancelled, stopping consumer",
+					zap.String("group_id", handler.GetGroupID()))
+				c.released(group)
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// batchConsumerGroupHandler implements sarama.ConsumerGroupHandler for batch handlers
+type batchConsumerGroupHandler struct {
+	client  *Client
+	group   *switchableGroup
+	handler BatchConsumerHandler
+	opts    BatchOptions
+	logger  *zap.Logger
+}
+
+// Setup is run before the consumer starts consuming
+func (h *batchConsumerGroupHandler) Setup(session sarama.ConsumerGroupSession) error {
+	h.client.rebalanced(h.group, session)
+	return nil
+}
+
+// Cleanup is run after the consumer stops consuming
+func (h *batchConsumerGroupHandler) Cleanup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// position is the context of a message in the errors reported
+func (h *batchConsumerGroupHandler) position(message *sarama.ConsumerMessage) *errreport.Message {
+	return &errreport.Message{
+		Topic:     message.Topic,
+		Partition: message.Partition,
+		Offset:    message.Offset,
+		Group:     h.handler.GetGroupID(),
+	}
+}
+
+// ConsumeClaim collects messages from a partition into batches. A batch is
+// handled once it is full or the flush interval elapses, and its offsets are
+// committed only after the handler succeeds. A failed batch ends the session
+// so the partition is consumed again from the last committed offset.
+func (h *batchConsumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	h.client.claimStarted(h.group, claim)
+	pending := make([]*sarama.ConsumerMessage, 0, h.opts.Size)
+	ticker := time.NewTicker(h.opts.FlushInterval)
+	defer ticker.Stop()
+
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+
+		start := time.Now()
+		messages := make([]*Message, 0, len(pending))
+		for _, kafkaMessage := range pending {
+			message, err := convertKafkaMessage(kafkaMessage)
+			if err == nil {
+				err = h.client.decryptMessage(session.Context(), message)
+			}
+			if err != nil {
+				ctx := errreport.WithMessage(session.Context(), h.position(kafkaMessage))
+				h.logger.Error("Failed to convert Kafka message",
+					zap.Error(err),
+					zap.String("topic", kafkaMessage.Topic),
+					zap.Int32("partition", kafkaMessage.Partition),
+					zap.Int64("offset", kafkaMessage.Offset),
+					zap.String("error_id", h.client.reportMessageError(ctx, err)))
+				continue
+			}
+			h.client.stats.observe(directionConsumed, kafkaMessage.Topic, message.EventType, consumedSize(kafkaMessage))
+			h.client.observe(message)
+			messages = append(messages, message)
+		}
+
+		if err := h.handler.HandleBatch(session.Context(), messages); err != nil {
+			h.client.metrics.ConsumerErrors.Inc()
+			err = fmt.Errorf("failed to handle batch of %d messages from %s/%d: %w",
+				len(pending), claim.Topic(), claim.Partition(), err)
+			// The batch is reported at the offset of its first message
+			h.client.reportMessageError(errreport.WithMessage(session.Context(), h.position(pending[0])), err)
+			return err
+		}
+
+		h.client.metrics.MessagesConsumed.Add(float64(len(messages)))
+		h.client.metrics.ConsumerLatency.Observe(time.Since(start).Seconds())
+
+		session.MarkMessage(pending[len(pending)-1], "")
+		session.Commit()
+		pending = pending[:0]
+		return nil
+	}
+
+	for {
+		select {
+		case message, ok := <-claim.Messages():
+			if !ok {
+				return flush()
+			}
+			pending = append(pending, message)
+			if len(pending) >= h.opts.Size {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+
+		case <-ticker.C:
+			if err := flush(); err != nil {
+				return err
+			}
+
+		case <-session.Context().Done():
+			// Unhandled messages are redelivered to the next owner of the partition
+			return nil
+		}
+	}
+}
@@ -6,14 +6,19 @@ package kafka
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/IBM/sarama"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/claimcheck"
 	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/envelope"
+	"github.com/Mir00r/X-Form-Backend/shared/errreport"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	gometrics "github.com/rcrowley/go-metrics"
 	"go.uber.org/zap"
 )
 
@@ -23,11 +28,49 @@ type Client struct {
 	config   *config.Config
 	logger   *zap.Logger
 	producer sarama.SyncProducer
-	consumer sarama.ConsumerGroup
+	consumer *switchableGroup
 	admin    sarama.ClusterAdmin
 	mutex    sync.RWMutex
 	closed   bool
 
+	// Offsets of partitions and consumer groups, read and committed with
+	// the client of the admin
+	offsets groupOffsets
+	// Health of the cluster, checked with the client of the admin
+	health *healthChecker
+
+	// Consumer groups started by StartBatchConsumer
+	batchConsumers []*switchableGroup
+	// Listeners of the assignment changes of the consumer groups
+	rebalanceListeners []RebalanceListener
+
+	// Failover of the producer to the secondary cluster, nil unless
+	// kafka.failover is enabled
+	failover     *failover
+	stopFailover context.CancelFunc
+
+	// Topics known to match their spec, skipped by auto-provisioning
+	topicsMutex sync.Mutex
+	provisioned map[string]bool
+
+	// Record format version messages are sized with
+	recordVersion int
+	// Store for payloads over max_message_bytes in the claim_check mode
+	claimChecks claimcheck.Store
+	// Encryption of the data of the messages of encrypted topics, nil
+	// unless kafka.encryption is configured
+	envelope *envelope.Envelope
+	// Messages being sent, bounded by kafka.producer.backpressure
+	inFlight inFlight
+	// Tracker of the messages that fail to be consumed
+	reporter errreport.Reporter
+	// Samples of the messages published and consumed by topic, nil unless
+	// kafka.stats is enabled
+	stats     *topicStats
+	stopStats context.CancelFunc
+	// Observer of the events published and consumed, nil unless set
+	observer EventObserver
+
 	// Metrics
 	metrics *KafkaMetrics
 }
@@ -43,6 +86,18 @@ type KafkaMetrics struct {
 	ConnectionStatus prometheus.Gauge
 	TopicsCount      prometheus.Gauge
 	PartitionsCount  prometheus.Gauge
+	MessageSize      prometheus.Histogram
+	CompressionRatio prometheus.GaugeFunc
+	ClaimChecks      prometheus.Counter
+
+	// Encryption of the data of messages, by operation: encrypt or decrypt
+	EncryptionLatency *prometheus.HistogramVec
+	EncryptionErrors  *prometheus.CounterVec
+
+	// Backpressure of the producer
+	InFlightMessages       prometheus.Gauge
+	InFlightBytes          prometheus.Gauge
+	BackpressureRejections prometheus.Counter
 }
 
 // Message represents a standardized event message structure
@@ -52,6 +107,7 @@ type Message struct {
 	CorrelationID string `json:"correlation_id"`
 	EventType     string `json:"event_type"`
 	Source        string `json:"source"`
+	Subject       string `json:"subject,omitempty"`
 
 	// Payload and metadata
 	Data     interface{}       `json:"data"`
@@ -94,9 +150,14 @@ func NewClient(cfg *config.Config, logger *zap.Logger) (*Client, error) {
 	}
 
 	client := &Client{
-		config:  cfg,
-		logger:  logger,
-		metrics: initMetrics(),
+		config:      cfg,
+		logger:      logger,
+		provisioned: make(map[string]bool),
+		reporter:    errreport.NewNoop(),
+		inFlight: inFlight{
+			maxMessages: cfg.Kafka.Producer.Backpressure.MaxInFlightMessages,
+			maxBytes:    cfg.Kafka.Producer.Backpressure.MaxInFlightBytes,
+		},
 	}
 
 	// Initialize Kafka configuration
@@ -104,6 +165,41 @@ func NewClient(cfg *config.Config, logger *zap.Logger) (*Client, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Kafka config: %w", err)
 	}
+	client.metrics = initMetrics(kafkaConfig.MetricRegistry)
+
+	// Messages are sized as the producer encodes them
+	client.recordVersion = 1
+	if kafkaConfig.Version.IsAtLeast(sarama.V0_11_0_0) {
+		client.recordVersion = 2
+	}
+
+	// Initialize the claim check store for oversized messages
+	if cfg.Kafka.Producer.LargeMessageMode == "claim_check" {
+		claimCheck := cfg.Kafka.Producer.ClaimCheck
+		store, err := claimcheck.NewS3Store(claimcheck.S3Config{
+			Endpoint:        claimCheck.Endpoint,
+			Region:          claimCheck.Region,
+			Bucket:          claimCheck.Bucket,
+			AccessKeyID:     claimCheck.AccessKeyID,
+			SecretAccessKey: claimCheck.SecretAccessKey,
+			ForcePathStyle:  claimCheck.ForcePathStyle,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create claim check store: %w", err)
+		}
+		client.claimChecks = store
+	}
+
+	// Initialize the keys of the encrypted topics
+	if cfg.Kafka.Encryption.Configured() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		envelope, err := newEnvelope(ctx, cfg.Kafka.Encryption)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create message encryption: %w", err)
+		}
+		client.envelope = envelope
+	}
 
 	// Initialize producer
 	if err := client.initProducer(kafkaConfig); err != nil {
@@ -123,6 +219,21 @@ func NewClient(cfg *config.Config, logger *zap.Logger) (*Client, error) {
 		return nil, fmt.Errorf("failed to initialize admin client: %w", err)
 	}
 
+	// Start sampling the topics
+	if cfg.Kafka.Stats.Enabled {
+		client.initStats()
+	}
+
+	// Initialize the secondary cluster
+	if cfg.Kafka.Failover.Enabled {
+		if err := client.initFailover(kafkaConfig); err != nil {
+			client.producer.Close()
+			client.consumer.Close()
+			client.admin.Close()
+			return nil, fmt.Errorf("failed to initialize kafka failover: %w", err)
+		}
+	}
+
 	// Update connection status metric
 	client.metrics.ConnectionStatus.Set(1)
 
@@ -134,6 +245,37 @@ func NewClient(cfg *config.Config, logger *zap.Logger) (*Client, error) {
 	return client, nil
 }
 
+// Ping connects to the brokers of cfg and reads the cluster metadata, with
+// the security settings of the client. It tells whether NewClient can
+// connect without registering the metrics NewClient registers, so it can be
+// retried while the brokers come up.
+func Ping(ctx context.Context, cfg *config.Config) error {
+	probe := &Client{config: cfg, logger: zap.NewNop()}
+	kafkaConfig, err := probe.createKafkaConfig()
+	if err != nil {
+		return fmt.Errorf("failed to create Kafka config: %w", err)
+	}
+	kafkaConfig.Metadata.Retry.Max = 0
+	if deadline, ok := ctx.Deadline(); ok {
+		kafkaConfig.Net.DialTimeout = min(kafkaConfig.Net.DialTimeout, time.Until(deadline))
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		client, err := sarama.NewClient(cfg.Kafka.Brokers, kafkaConfig)
+		if err == nil {
+			client.Close()
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // createKafkaConfig creates Sarama configuration from service config
 func (c *Client) createKafkaConfig() (*sarama.Config, error) {
 	kafkaConfig := sarama.NewConfig()
@@ -158,7 +300,9 @@ func (c *Client) createKafkaConfig() (*sarama.Config, error) {
 	c.configureProducer(kafkaConfig)
 
 	// Configure consumer
-	c.configureConsumer(kafkaConfig)
+	if err := c.configureConsumer(kafkaConfig); err != nil {
+		return nil, fmt.Errorf("failed to configure consumer: %w", err)
+	}
 
 	// Configure admin
 	kafkaConfig.Admin.Timeout = c.config.Kafka.Admin.Timeout
@@ -259,20 +403,7 @@ func (c *Client) configureProducer(kafkaConfig *sarama.Config) {
 	kafkaConfig.Producer.Retry.Backoff = producerConfig.RetryBackoff
 
 	// Compression settings
-	switch producerConfig.Compression {
-	case "none":
-		kafkaConfig.Producer.Compression = sarama.CompressionNone
-	case "gzip":
-		kafkaConfig.Producer.Compression = sarama.CompressionGZIP
-	case "snappy":
-		kafkaConfig.Producer.Compression = sarama.CompressionSnappy
-	case "lz4":
-		kafkaConfig.Producer.Compression = sarama.CompressionLZ4
-	case "zstd":
-		kafkaConfig.Producer.Compression = sarama.CompressionZSTD
-	default:
-		kafkaConfig.Producer.Compression = sarama.CompressionSnappy
-	}
+	kafkaConfig.Producer.Compression = compressionCodec(producerConfig.Compression)
 
 	// Message settings
 	kafkaConfig.Producer.MaxMessageBytes = producerConfig.MaxMessageBytes
@@ -299,12 +430,26 @@ func (c *Client) configureProducer(kafkaConfig *sarama.Config) {
 }
 
 // configureConsumer configures consumer settings
-func (c *Client) configureConsumer(kafkaConfig *sarama.Config) {
+func (c *Client) configureConsumer(kafkaConfig *sarama.Config) error {
 	consumerConfig := c.config.Kafka.Consumer
 
 	// Group configuration
 	kafkaConfig.Consumer.Group.Session.Timeout = consumerConfig.SessionTimeout
 	kafkaConfig.Consumer.Group.Heartbeat.Interval = consumerConfig.HeartbeatInterval
+	strategies, err := balanceStrategies(consumerConfig.RebalanceStrategy)
+	if err != nil {
+		return err
+	}
+	kafkaConfig.Consumer.Group.Rebalance.GroupStrategies = strategies
+
+	// Static membership: members restarting within the session timeout
+	// rejoin with their partitions and without a rebalance
+	if consumerConfig.InstanceID != "" {
+		if !kafkaConfig.Version.IsAtLeast(sarama.V2_3_0_0) {
+			return fmt.Errorf("static group membership requires kafka version 2.3.0 or later, got %s", kafkaConfig.Version)
+		}
+		kafkaConfig.Consumer.Group.InstanceId = consumerConfig.InstanceID
+	}
 
 	// Offset configuration
 	switch consumerConfig.AutoOffsetReset {
@@ -344,6 +489,7 @@ func (c *Client) configureConsumer(kafkaConfig *sarama.Config) {
 
 	// Return errors
 	kafkaConfig.Consumer.Return.Errors = consumerConfig.ReturnErrors
+	return nil
 }
 
 // initProducer initializes the Kafka producer
@@ -360,9 +506,12 @@ func (c *Client) initProducer(kafkaConfig *sarama.Config) error {
 
 // initConsumer initializes the Kafka consumer group
 func (c *Client) initConsumer(kafkaConfig *sarama.Config) error {
-	consumer, err := sarama.NewConsumerGroup(c.config.Kafka.Brokers, c.config.Kafka.Consumer.GroupID, kafkaConfig)
+	consumer, err := openConsumerGroup(c.config.Kafka.Brokers, c.config.Kafka.Consumer.GroupID, kafkaConfig, func(err error) {
+		c.logger.Error("Consumer group error", zap.Error(err))
+		c.metrics.ConsumerErrors.Inc()
+	})
 	if err != nil {
-		return fmt.Errorf("failed to create consumer group: %w", err)
+		return err
 	}
 
 	c.consumer = consumer
@@ -373,20 +522,44 @@ func (c *Client) initConsumer(kafkaConfig *sarama.Config) error {
 
 // initAdmin initializes the Kafka admin client
 func (c *Client) initAdmin(kafkaConfig *sarama.Config) error {
-	admin, err := sarama.NewClusterAdmin(c.config.Kafka.Brokers, kafkaConfig)
+	client, err := sarama.NewClient(c.config.Kafka.Brokers, kafkaConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create admin client: %w", err)
+	}
+	// Closing the admin closes the client
+	admin, err := sarama.NewClusterAdminFromClient(client)
 	if err != nil {
+		client.Close()
 		return fmt.Errorf("failed to create admin client: %w", err)
 	}
 
 	c.admin = admin
+	c.offsets = saramaOffsets{Client: client}
+
+	// Pings of the health check connect with its timeout
+	healthConfig := c.config.Kafka.Health
+	probeConfig := *kafkaConfig
+	if healthConfig.Timeout > 0 {
+		probeConfig.Net.DialTimeout = healthConfig.Timeout
+		probeConfig.Net.ReadTimeout = healthConfig.Timeout
+		probeConfig.Net.WriteTimeout = healthConfig.Timeout
+	}
+	c.health = newHealthChecker(healthConfig, c.config.Kafka.Brokers, &saramaProbe{client: client, producer: c.producer, conf: &probeConfig})
 	c.logger.Info("Kafka admin client initialized successfully")
 	return nil
 }
 
 // PublishMessage publishes a message to Kafka
 func (c *Client) PublishMessage(ctx context.Context, message *Message) error {
+	_, _, err := c.SendMessage(ctx, message)
+	return err
+}
+
+// SendMessage publishes a message to Kafka and returns the partition and
+// offset it was written at
+func (c *Client) SendMessage(ctx context.Context, message *Message) (int32, int64, error) {
 	if c.closed {
-		return fmt.Errorf("kafka client is closed")
+		return 0, 0, fmt.Errorf("kafka client is closed")
 	}
 
 	start := time.Now()
@@ -395,32 +568,91 @@ func (c *Client) PublishMessage(ctx context.Context, message *Message) error {
 		c.metrics.ProducerLatency.Observe(duration.Seconds())
 	}()
 
+	// Create the topic before its first message when auto-provisioning;
+	// topics of the secondary cluster are mirrored, not provisioned
+	if c.config.Kafka.Topics.AutoProvision && c.failover.onPrimary() {
+		if err := c.ensureProvisioned(ctx, message.Topic); err != nil {
+			c.metrics.ProducerErrors.Inc()
+			return 0, 0, fmt.Errorf("failed to provision topic %s: %w", message.Topic, err)
+		}
+	}
+
+	// Encrypt the data of the messages of encrypted topics
+	if c.config.Kafka.Topics.SpecFor(message.Topic).Encrypted {
+		if c.envelope == nil {
+			c.metrics.ProducerErrors.Inc()
+			return 0, 0, fmt.Errorf("topic %s is encrypted but kafka.encryption is not configured", message.Topic)
+		}
+		encrypted, err := c.encryptMessage(ctx, message)
+		if err != nil {
+			c.metrics.ProducerErrors.Inc()
+			return 0, 0, fmt.Errorf("failed to encrypt message: %w", err)
+		}
+		message = encrypted
+	}
+
 	// Prepare Kafka message
 	kafkaMessage, err := c.prepareKafkaMessage(message)
 	if err != nil {
 		c.metrics.ProducerErrors.Inc()
-		return fmt.Errorf("failed to prepare message: %w", err)
+		return 0, 0, fmt.Errorf("failed to prepare message: %w", err)
+	}
+
+	// Reject or spill messages the brokers would refuse
+	c.metrics.MessageSize.Observe(float64(kafkaMessage.ByteSize(c.recordVersion)))
+	prepared, err := c.fitMessage(ctx, message, kafkaMessage)
+	if err != nil {
+		c.metrics.ProducerErrors.Inc()
+		return 0, 0, err
 	}
+	if prepared != kafkaMessage {
+		c.metrics.ClaimChecks.Inc()
+		c.logger.Info("Oversized message published as a claim check",
+			zap.String("topic", message.Topic),
+			zap.String("message_id", message.ID),
+			zap.Int("size", kafkaMessage.ByteSize(c.recordVersion)))
+		kafkaMessage = prepared
+	}
+
+	// Refuse the message rather than queue it behind slow brokers
+	size := kafkaMessage.ByteSize(c.recordVersion)
+	if err := c.inFlight.acquire(size); err != nil {
+		c.metrics.BackpressureRejections.Inc()
+		return 0, 0, err
+	}
+	c.metrics.InFlightMessages.Inc()
+	c.metrics.InFlightBytes.Add(float64(size))
+	defer func() {
+		c.inFlight.release(size)
+		c.metrics.InFlightMessages.Dec()
+		c.metrics.InFlightBytes.Sub(float64(size))
+	}()
 
 	// Send message
-	partition, offset, err := c.producer.SendMessage(kafkaMessage)
+	partition, offset, err := c.send(ctx, kafkaMessage)
+	if errors.Is(err, ErrBackpressure) {
+		c.metrics.BackpressureRejections.Inc()
+		return 0, 0, err
+	}
 	if err != nil {
 		c.metrics.ProducerErrors.Inc()
 		c.logger.Error("Failed to publish message",
 			zap.String("topic", message.Topic),
 			zap.String("message_id", message.ID),
 			zap.Error(err))
-		return fmt.Errorf("failed to send message: %w", err)
+		return 0, 0, fmt.Errorf("failed to send message: %w", err)
 	}
 
 	c.metrics.MessagesProduced.Inc()
+	c.stats.observe(directionPublished, message.Topic, message.EventType, size)
+	c.observe(message)
 	c.logger.Debug("Message published successfully",
 		zap.String("topic", message.Topic),
 		zap.String("message_id", message.ID),
 		zap.Int32("partition", partition),
 		zap.Int64("offset", offset))
 
-	return nil
+	return partition, offset, nil
 }
 
 // PublishMessageAsync publishes a message asynchronously with callback
@@ -454,6 +686,7 @@ func (c *Client) StartConsumer(ctx context.Context, handler ConsumerHandler) err
 	// Create consumer group handler
 	consumerHandler := &consumerGroupHandler{
 		client:  c,
+		group:   c.consumer,
 		handler: handler,
 		logger:  c.logger,
 	}
@@ -464,9 +697,10 @@ func (c *Client) StartConsumer(ctx context.Context, handler ConsumerHandler) err
 			select {
 			case <-ctx.Done():
 				c.logger.Info("Consumer context cancelled, stopping consumer")
+				c.released(c.consumer)
 				return
 			default:
-				if err := c.consumer.Consume(ctx, topics, consumerHandler); err != nil {
+				if err := c.consumer.current().Consume(ctx, topics, consumerHandler); err != nil {
 					c.logger.Error("Consumer error", zap.Error(err))
 					c.metrics.ConsumerErrors.Inc()
 				}
@@ -474,14 +708,6 @@ func (c *Client) StartConsumer(ctx context.Context, handler ConsumerHandler) err
 		}
 	}()
 
-	// Handle consumer errors
-	go func() {
-		for err := range c.consumer.Errors() {
-			c.logger.Error("Consumer group error", zap.Error(err))
-			c.metrics.ConsumerErrors.Inc()
-		}
-	}()
-
 	return nil
 }
 
@@ -562,20 +788,39 @@ func (c *Client) GetTopicMetadata(ctx context.Context, topicName string) (*saram
 	return nil, fmt.Errorf("topic %s not found", topicName)
 }
 
-// HealthCheck performs a health check on the Kafka client
-func (c *Client) HealthCheck(ctx context.Context) error {
+// Health checks that enough brokers are reachable, that the critical
+// topics have leaders and, with the canary, that a message makes the round
+// trip. Results are cached for kafka.health.cache_ttl.
+func (c *Client) Health(ctx context.Context) *Health {
 	if c.closed {
-		return fmt.Errorf("kafka client is closed")
+		return &Health{Status: StatusUnhealthy, Error: "kafka client is closed", CheckedAt: time.Now()}
 	}
 
-	// Check if we can list topics (basic connectivity test)
-	_, err := c.ListTopics(ctx)
-	if err != nil {
+	health := c.health.Health(ctx)
+	if c.failover != nil {
+		// The health of the cluster messages are published to, with the
+		// state of the failover
+		status := c.failover.Status()
+		if status.ProducerCluster == ClusterSecondary {
+			health = c.failover.clusters[1].health.Health(ctx)
+		}
+		withFailover := *health
+		withFailover.Failover = status
+		health = &withFailover
+	}
+	if health.Status == StatusHealthy {
+		c.metrics.ConnectionStatus.Set(1)
+	} else {
 		c.metrics.ConnectionStatus.Set(0)
-		return fmt.Errorf("kafka health check failed: %w", err)
 	}
+	return health
+}
 
-	c.metrics.ConnectionStatus.Set(1)
+// HealthCheck performs a health check on the Kafka client
+func (c *Client) HealthCheck(ctx context.Context) error {
+	if health := c.Health(ctx); health.Status != StatusHealthy {
+		return fmt.Errorf("kafka health check failed: %s", health.Error)
+	}
 	return nil
 }
 
@@ -604,6 +849,13 @@ func (c *Client) Close() error {
 		}
 	}
 
+	// Close batch consumer groups
+	for _, group := range c.batchConsumers {
+		if err := group.Close(); err != nil {
+			errors = append(errors, fmt.Errorf("failed to close batch consumer: %w", err))
+		}
+	}
+
 	// Close admin client
 	if c.admin != nil {
 		if err := c.admin.Close(); err != nil {
@@ -611,6 +863,22 @@ func (c *Client) Close() error {
 		}
 	}
 
+	if c.stopStats != nil {
+		c.stopStats()
+	}
+
+	// Close the secondary cluster
+	if c.failover != nil {
+		c.stopFailover()
+		secondary := c.failover.clusters[1]
+		if err := secondary.producer.Close(); err != nil {
+			errors = append(errors, fmt.Errorf("failed to close secondary producer: %w", err))
+		}
+		if err := secondary.admin.Close(); err != nil {
+			errors = append(errors, fmt.Errorf("failed to close secondary client: %w", err))
+		}
+	}
+
 	c.closed = true
 	c.metrics.ConnectionStatus.Set(0)
 
@@ -624,8 +892,8 @@ func (c *Client) Close() error {
 
 // prepareKafkaMessage converts internal Message to Sarama ProducerMessage
 func (c *Client) prepareKafkaMessage(message *Message) (*sarama.ProducerMessage, error) {
-	// Serialize message data
-	value, err := c.serializeMessage(message)
+	// Serialize message in the format configured for the topic
+	value, contentType, err := EncodeMessage(message, c.config.Kafka.EventFormatFor(message.Topic))
 	if err != nil {
 		return nil, fmt.Errorf("failed to serialize message: %w", err)
 	}
@@ -661,36 +929,15 @@ func (c *Client) prepareKafkaMessage(message *Message) (*sarama.ProducerMessage,
 		sarama.RecordHeader{Key: []byte("correlation-id"), Value: []byte(message.CorrelationID)},
 		sarama.RecordHeader{Key: []byte("event-type"), Value: []byte(message.EventType)},
 		sarama.RecordHeader{Key: []byte("source"), Value: []byte(message.Source)},
-		sarama.RecordHeader{Key: []byte("content-type"), Value: []byte(message.Metadata.ContentType)},
+		sarama.RecordHeader{Key: []byte("content-type"), Value: []byte(contentType)},
 		sarama.RecordHeader{Key: []byte("schema-version"), Value: []byte(message.Metadata.SchemaVersion)},
 	)
 
 	return kafkaMessage, nil
 }
 
-// serializeMessage serializes message data to bytes
-func (c *Client) serializeMessage(message *Message) ([]byte, error) {
-	// This is a simplified JSON serialization
-	// In production, you might want to use Avro, Protocol Buffers, or other formats
-	return []byte(fmt.Sprintf(`{
-		"id": "%s",
-		"correlation_id": "%s",
-		"event_type": "%s",
-		"source": "%s",
-		"data": %v,
-		"metadata": {
-			"timestamp": "%s",
-			"version": "%s",
-			"content_type": "%s",
-			"encoding": "%s"
-		}
-	}`, message.ID, message.CorrelationID, message.EventType, message.Source,
-		message.Data, message.Metadata.Timestamp.Format(time.RFC3339),
-		message.Metadata.Version, message.Metadata.ContentType, message.Metadata.Encoding)), nil
-}
-
 // initMetrics initializes Prometheus metrics for Kafka operations
-func initMetrics() *KafkaMetrics {
+func initMetrics(registry gometrics.Registry) *KafkaMetrics {
 	return &KafkaMetrics{
 		MessagesProduced: promauto.NewCounter(prometheus.CounterOpts{
 			Name: "kafka_messages_produced_total",
@@ -730,19 +977,58 @@ func initMetrics() *KafkaMetrics {
 			Name: "kafka_partitions_count",
 			Help: "Number of Kafka partitions",
 		}),
+		MessageSize: promauto.NewHistogram(prometheus.HistogramOpts{
+			Name:    "kafka_producer_message_size_bytes",
+			Help:    "Histogram of the size of produced messages before compression",
+			Buckets: prometheus.ExponentialBuckets(256, 4, 8),
+		}),
+		CompressionRatio: promauto.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "kafka_producer_compression_ratio",
+			Help: "Mean ratio of uncompressed to compressed size of produced batches",
+		}, func() float64 {
+			// The producer records the ratio multiplied by 100
+			return gometrics.GetOrRegisterHistogram("compression-ratio", registry, gometrics.NewExpDecaySample(1028, 0.015)).Snapshot().Mean() / 100
+		}),
+		ClaimChecks: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "kafka_producer_claim_checks_total",
+			Help: "Total number of oversized messages published as claim checks",
+		}),
+		EncryptionLatency: promauto.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "kafka_message_encryption_duration_seconds",
+			Help:    "Histogram of the time taken to encrypt or decrypt the data of messages, KMS calls included",
+			Buckets: []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .05, .1, .5, 1},
+		}, []string{"operation"}),
+		EncryptionErrors: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "kafka_message_encryption_errors_total",
+			Help: "Total number of messages whose data failed to encrypt or decrypt",
+		}, []string{"operation"}),
+		InFlightMessages: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "kafka_producer_in_flight_messages",
+			Help: "Number of messages being sent to the brokers",
+		}),
+		InFlightBytes: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "kafka_producer_in_flight_bytes",
+			Help: "Size of the messages being sent to the brokers",
+		}),
+		BackpressureRejections: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "kafka_producer_backpressure_rejections_total",
+			Help: "Total number of messages refused while the in-flight limits were reached",
+		}),
 	}
 }
 
 // consumerGroupHandler implements sarama.ConsumerGroupHandler
 type consumerGroupHandler struct {
 	client  *Client
+	group   *switchableGroup
 	handler ConsumerHandler
 	logger  *zap.Logger
 }
 
 // Setup is run before the consumer starts consuming
-func (h *consumerGroupHandler) Setup(sarama.ConsumerGroupSession) error {
+func (h *consumerGroupHandler) Setup(session sarama.ConsumerGroupSession) error {
 	h.logger.Info("Consumer group session setup")
+	h.client.rebalanced(h.group, session)
 	return nil
 }
 
@@ -754,6 +1040,7 @@ func (h *consumerGroupHandler) Cleanup(sarama.ConsumerGroupSession) error {
 
 // ConsumeClaim processes messages from a partition
 func (h *consumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	h.client.claimStarted(h.group, claim)
 	for {
 		select {
 		case message := <-claim.Messages():
@@ -762,25 +1049,49 @@ func (h *consumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession,
 			}
 
 			start := time.Now()
+			// Errors reported while the message is processed are attached
+			// to it
+			position := &errreport.Message{
+				Topic:     message.Topic,
+				Partition: message.Partition,
+				Offset:    message.Offset,
+				Group:     h.handler.GetGroupID(),
+			}
+			ctx := errreport.WithMessage(session.Context(), position)
 
 			// Convert Kafka message to internal Message
-			internalMessage, err := h.convertKafkaMessage(message)
+			internalMessage, err := convertKafkaMessage(message)
 			if err != nil {
 				h.logger.Error("Failed to convert Kafka message",
 					zap.Error(err),
 					zap.String("topic", message.Topic),
 					zap.Int32("partition", message.Partition),
-					zap.Int64("offset", message.Offset))
+					zap.Int64("offset", message.Offset),
+					zap.String("error_id", h.client.reportMessageError(ctx, err)))
 				continue
 			}
 
+			h.client.stats.observe(directionConsumed, message.Topic, internalMessage.EventType, consumedSize(message))
+
 			// Process message with handler
-			ctx := session.Context()
+			if err := h.client.decryptMessage(ctx, internalMessage); err != nil {
+				h.logger.Error("Failed to decrypt Kafka message",
+					zap.Error(err),
+					zap.String("topic", message.Topic),
+					zap.Int32("partition", message.Partition),
+					zap.Int64("offset", message.Offset),
+					zap.String("error_id", h.client.reportMessageError(ctx, err)))
+				h.client.metrics.ConsumerErrors.Inc()
+				continue
+			}
+			position.ID, position.EventType = internalMessage.ID, internalMessage.EventType
+			h.client.observe(internalMessage)
 			if err := h.handler.Handle(ctx, internalMessage); err != nil {
 				h.logger.Error("Failed to handle message",
 					zap.Error(err),
 					zap.String("message_id", internalMessage.ID),
-					zap.String("topic", message.Topic))
+					zap.String("topic", message.Topic),
+					zap.String("error_id", h.client.reportMessageError(ctx, err)))
 				h.client.metrics.ConsumerErrors.Inc()
 			} else {
 				h.client.metrics.MessagesConsumed.Inc()
@@ -802,8 +1113,39 @@ func (h *consumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession,
 	}
 }
 
+// EventObserver sees the events the client publishes and consumes. It is
+// called on the publish and consume paths, so it must not block.
+type EventObserver interface {
+	ObserveEvent(message *Message)
+}
+
+// SetObserver sets the observer of the events published and consumed,
+// before the client starts consuming
+func (c *Client) SetObserver(observer EventObserver) {
+	c.observer = observer
+}
+
+// observe hands a message published or consumed to the observer, if any
+func (c *Client) observe(message *Message) {
+	if c.observer != nil {
+		c.observer.ObserveEvent(message)
+	}
+}
+
+// SetErrorReporter sets the tracker the messages that fail to be consumed
+// are reported to, instead of none
+func (c *Client) SetErrorReporter(reporter errreport.Reporter) {
+	c.reporter = reporter
+}
+
+// reportMessageError reports an error consuming the message of ctx and
+// returns the ID of the event
+func (c *Client) reportMessageError(ctx context.Context, err error) string {
+	return c.reporter.Report(ctx, &errreport.Event{Err: err, Message: errreport.MessageFrom(ctx)})
+}
+
 // convertKafkaMessage converts Sarama ConsumerMessage to internal Message
-func (h *consumerGroupHandler) convertKafkaMessage(kafkaMessage *sarama.ConsumerMessage) (*Message, error) {
+func convertKafkaMessage(kafkaMessage *sarama.ConsumerMessage) (*Message, error) {
 	// Extract headers
 	headers := make(map[string]string)
 	var eventID, correlationID, eventType, source, contentType, schemaVersion string
@@ -830,24 +1172,39 @@ func (h *consumerGroupHandler) convertKafkaMessage(kafkaMessage *sarama.Consumer
 		}
 	}
 
-	// Create internal message
-	message := &Message{
-		ID:            eventID,
-		CorrelationID: correlationID,
-		EventType:     eventType,
-		Source:        source,
-		Data:          kafkaMessage.Value, // Raw data - should be deserialized based on content type
-		Headers:       headers,
-		Topic:         kafkaMessage.Topic,
-		Partition:     kafkaMessage.Partition,
-		Key:           string(kafkaMessage.Key),
-		Metadata: MessageMetadata{
-			Timestamp:     kafkaMessage.Timestamp,
-			ContentType:   contentType,
-			SchemaVersion: schemaVersion,
-			Encoding:      "utf-8",
-		},
+	// Unwrap the value, whichever format it was published in
+	message, err := DecodeMessage(kafkaMessage.Value, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	// Headers fill in anything the payload didn't carry
+	if message.ID == "" {
+		message.ID = eventID
+	}
+	if message.CorrelationID == "" {
+		message.CorrelationID = correlationID
+	}
+	if message.EventType == "" {
+		message.EventType = eventType
+	}
+	if message.Source == "" {
+		message.Source = source
+	}
+	if message.Metadata.Timestamp.IsZero() {
+		message.Metadata.Timestamp = kafkaMessage.Timestamp
+	}
+	if message.Metadata.ContentType == "" {
+		message.Metadata.ContentType = contentType
+	}
+	if message.Metadata.Encoding == "" {
+		message.Metadata.Encoding = "utf-8"
 	}
+	message.Metadata.SchemaVersion = schemaVersion
+	message.Headers = headers
+	message.Topic = kafkaMessage.Topic
+	message.Partition = kafkaMessage.Partition
+	message.Key = string(kafkaMessage.Key)
 
 	return message, nil
 }
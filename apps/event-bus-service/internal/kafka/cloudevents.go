@@ -0,0 +1,208 @@
+package kafka
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Event formats messages can be published in
+const (
+	// EventFormatNative is the Message JSON shape the event bus has always used
+	EventFormatNative = "native"
+	// EventFormatCloudEvents is CloudEvents 1.0 structured-mode JSON
+	EventFormatCloudEvents = "cloudevents"
+)
+
+const (
+	// CloudEventsSpecVersion is the CloudEvents version produced and accepted
+	CloudEventsSpecVersion = "1.0"
+	// CloudEventsContentType is the content-type of structured-mode CloudEvents
+	CloudEventsContentType = "application/cloudevents+json; charset=UTF-8"
+)
+
+// CloudEvent is a CloudEvents 1.0 event in structured-mode JSON
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            *time.Time      `json:"time,omitempty"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	DataSchema      string          `json:"dataschema,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+}
+
+// Validate checks the attributes CloudEvents 1.0 requires
+func (e *CloudEvent) Validate() error {
+	if e.SpecVersion != CloudEventsSpecVersion {
+		return fmt.Errorf("unsupported specversion %q", e.SpecVersion)
+	}
+	if e.ID == "" {
+		return fmt.Errorf("id is required")
+	}
+	if e.Source == "" {
+		return fmt.Errorf("source is required")
+	}
+	if e.Type == "" {
+		return fmt.Errorf("type is required")
+	}
+	return nil
+}
+
+// ToCloudEvent wraps a message into a CloudEvents envelope
+func ToCloudEvent(message *Message) (*CloudEvent, error) {
+	event := &CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              message.ID,
+		Source:          message.Source,
+		Type:            message.EventType,
+		Subject:         message.Subject,
+		DataContentType: message.Metadata.ContentType,
+	}
+	if event.DataContentType == "" {
+		event.DataContentType = "application/json"
+	}
+	if !message.Metadata.Timestamp.IsZero() {
+		timestamp := message.Metadata.Timestamp.UTC()
+		event.Time = &timestamp
+	}
+
+	if raw, ok := message.Data.(json.RawMessage); ok {
+		// Data published as received is encoded already
+		event.Data = raw
+	} else if message.Data != nil {
+		data, err := json.Marshal(message.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode event data: %w", err)
+		}
+		event.Data = data
+	}
+
+	return event, nil
+}
+
+// FromCloudEvent unwraps a CloudEvents envelope into a message
+func FromCloudEvent(event *CloudEvent) (*Message, error) {
+	message := &Message{
+		ID:        event.ID,
+		EventType: event.Type,
+		Source:    event.Source,
+		Subject:   event.Subject,
+		Headers:   make(map[string]string),
+		Metadata: MessageMetadata{
+			Version:     event.SpecVersion,
+			ContentType: event.DataContentType,
+			Encoding:    "utf-8",
+		},
+	}
+	if event.Time != nil {
+		message.Metadata.Timestamp = *event.Time
+	}
+
+	if len(event.Data) > 0 {
+		var data interface{}
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			return nil, fmt.Errorf("failed to decode event data: %w", err)
+		}
+		message.Data = data
+	}
+
+	return message, nil
+}
+
+// IsCloudEvent reports whether a JSON document is a structured-mode
+// CloudEvent, which is recognised by its specversion attribute
+func IsCloudEvent(body []byte) bool {
+	var probe struct {
+		SpecVersion *string `json:"specversion"`
+	}
+	if err := json.Unmarshal(body, &probe); err != nil {
+		return false
+	}
+	return probe.SpecVersion != nil
+}
+
+// ParseCloudEvent decodes and validates a structured-mode CloudEvent
+func ParseCloudEvent(body []byte) (*CloudEvent, error) {
+	var event CloudEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("invalid CloudEvent: %w", err)
+	}
+	if err := event.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid CloudEvent: %w", err)
+	}
+	return &event, nil
+}
+
+// EncodeMessage serializes a message in the given format and returns the
+// content-type to publish it with
+func EncodeMessage(message *Message, format string) ([]byte, string, error) {
+	if format == EventFormatCloudEvents {
+		event, err := ToCloudEvent(message)
+		if err != nil {
+			return nil, "", err
+		}
+		value, err := json.Marshal(event)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to encode CloudEvent: %w", err)
+		}
+		return value, CloudEventsContentType, nil
+	}
+
+	value, err := json.Marshal(nativeMessage{
+		ID:            message.ID,
+		CorrelationID: message.CorrelationID,
+		EventType:     message.EventType,
+		Source:        message.Source,
+		Subject:       message.Subject,
+		Data:          message.Data,
+		Metadata:      message.Metadata,
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode message: %w", err)
+	}
+	return value, message.Metadata.ContentType, nil
+}
+
+// DecodeMessage parses a message value in either format. CloudEvents are
+// recognised by their content-type or, failing that, their specversion
+// attribute. Values that are neither are returned with the raw bytes as data.
+func DecodeMessage(value []byte, contentType string) (*Message, error) {
+	if strings.HasPrefix(contentType, "application/cloudevents+json") || IsCloudEvent(value) {
+		event, err := ParseCloudEvent(value)
+		if err != nil {
+			return nil, err
+		}
+		return FromCloudEvent(event)
+	}
+
+	var native nativeMessage
+	if err := json.Unmarshal(value, &native); err != nil || native.EventType == "" {
+		return &Message{Data: value, Headers: make(map[string]string)}, nil
+	}
+
+	return &Message{
+		ID:            native.ID,
+		CorrelationID: native.CorrelationID,
+		EventType:     native.EventType,
+		Source:        native.Source,
+		Subject:       native.Subject,
+		Data:          native.Data,
+		Headers:       make(map[string]string),
+		Metadata:      native.Metadata,
+	}, nil
+}
+
+// nativeMessage is the serialized form of a Message in the native format
+type nativeMessage struct {
+	ID            string          `json:"id"`
+	CorrelationID string          `json:"correlation_id"`
+	EventType     string          `json:"event_type"`
+	Source        string          `json:"source"`
+	Subject       string          `json:"subject,omitempty"`
+	Data          interface{}     `json:"data"`
+	Metadata      MessageMetadata `json:"metadata"`
+}
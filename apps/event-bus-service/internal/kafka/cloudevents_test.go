@@ -0,0 +1,131 @@
+package kafka
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testMessage() *Message {
+	return &Message{
+		ID:        "event_1",
+		EventType: "form.published",
+		Source:    "form-service",
+		Subject:   "form-42",
+		Data: map[string]interface{}{
+			"form_id":   "form-42",
+			"questions": []interface{}{"q1", "q2"},
+			"count":     2.0,
+		},
+		Topic: "app.form.published",
+		Metadata: MessageMetadata{
+			Timestamp:   time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+			Version:     "1.0",
+			ContentType: "application/json",
+			Encoding:    "utf-8",
+		},
+	}
+}
+
+func TestCloudEventRoundTrip(t *testing.T) {
+	original := testMessage()
+
+	value, contentType, err := EncodeMessage(original, EventFormatCloudEvents)
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+	if contentType != CloudEventsContentType {
+		t.Errorf("expected content-type %q, got %q", CloudEventsContentType, contentType)
+	}
+
+	var envelope map[string]interface{}
+	if err := json.Unmarshal(value, &envelope); err != nil {
+		t.Fatalf("envelope is not JSON: %v", err)
+	}
+	for attribute, want := range map[string]interface{}{
+		"specversion":     "1.0",
+		"id":              "event_1",
+		"source":          "form-service",
+		"type":            "form.published",
+		"subject":         "form-42",
+		"time":            "2026-03-01T12:00:00Z",
+		"datacontenttype": "application/json",
+	} {
+		if envelope[attribute] != want {
+			t.Errorf("%s: expected %v, got %v", attribute, want, envelope[attribute])
+		}
+	}
+
+	decoded, err := DecodeMessage(value, contentType)
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	assertSameEvent(t, original, decoded)
+}
+
+func TestNativeRoundTrip(t *testing.T) {
+	original := testMessage()
+
+	value, contentType, err := EncodeMessage(original, EventFormatNative)
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+	if IsCloudEvent(value) {
+		t.Fatal("native message detected as a CloudEvent")
+	}
+
+	decoded, err := DecodeMessage(value, contentType)
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	assertSameEvent(t, original, decoded)
+}
+
+func TestDecodeMessageDetectsCloudEventsWithoutContentType(t *testing.T) {
+	value, _, err := EncodeMessage(testMessage(), EventFormatCloudEvents)
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+
+	decoded, err := DecodeMessage(value, "")
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	assertSameEvent(t, testMessage(), decoded)
+}
+
+func TestDecodeMessageKeepsUnknownPayloads(t *testing.T) {
+	raw := []byte(`not json`)
+
+	decoded, err := DecodeMessage(raw, "text/plain")
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if !reflect.DeepEqual(decoded.Data, raw) {
+		t.Errorf("expected raw payload to be kept, got %v", decoded.Data)
+	}
+}
+
+func TestParseCloudEventRequiresAttributes(t *testing.T) {
+	if _, err := ParseCloudEvent([]byte(`{"specversion":"1.0","id":"1","source":"s"}`)); err == nil {
+		t.Error("expected missing type to be rejected")
+	}
+	if _, err := ParseCloudEvent([]byte(`{"specversion":"0.3","id":"1","source":"s","type":"t"}`)); err == nil {
+		t.Error("expected unsupported specversion to be rejected")
+	}
+}
+
+func assertSameEvent(t *testing.T, want, got *Message) {
+	t.Helper()
+
+	if got.ID != want.ID || got.EventType != want.EventType || got.Source != want.Source || got.Subject != want.Subject {
+		t.Errorf("attributes differ: want %+v, got %+v", want, got)
+	}
+	if !got.Metadata.Timestamp.Equal(want.Metadata.Timestamp) {
+		t.Errorf("timestamp differs: want %v, got %v", want.Metadata.Timestamp, got.Metadata.Timestamp)
+	}
+	if !reflect.DeepEqual(got.Data, want.Data) {
+		t.Errorf("data differs: want %v, got %v", want.Data, got.Data)
+	}
+}
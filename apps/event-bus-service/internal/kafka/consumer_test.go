@@ -0,0 +1,88 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/envelope"
+	"github.com/Mir00r/X-Form-Backend/shared/errreport"
+)
+
+// fakeSession is a consumer group session marking the messages consumed
+type fakeSession struct {
+	sarama.ConsumerGroupSession
+	ctx    context.Context
+	marked []int64
+}
+
+func (s *fakeSession) Context() context.Context { return s.ctx }
+
+func (s *fakeSession) MarkMessage(message *sarama.ConsumerMessage, _ string) {
+	s.marked = append(s.marked, message.Offset)
+}
+
+// fakeClaim is the claim of a partition whose messages are all buffered
+type fakeClaim struct {
+	sarama.ConsumerGroupClaim
+	messages chan *sarama.ConsumerMessage
+}
+
+func (c *fakeClaim) Messages() <-chan *sarama.ConsumerMessage { return c.messages }
+
+// failingHandler fails to handle the messages of type form.deleted
+type failingHandler struct{}
+
+func (failingHandler) Handle(_ context.Context, message *Message) error {
+	if message.EventType == "form.deleted" {
+		return errors.New("form 42 not found")
+	}
+	return nil
+}
+
+func (failingHandler) GetTopics() []string { return []string{"app.form.updated"} }
+func (failingHandler) GetGroupID() string  { return "event-bus" }
+
+func TestConsumeClaimReportsErrors(t *testing.T) {
+	metrics := testMetrics()
+	metrics.MessagesConsumed = prometheus.NewCounter(prometheus.CounterOpts{Name: "consumed"})
+	metrics.ConsumerErrors = prometheus.NewCounter(prometheus.CounterOpts{Name: "consumer_errors"})
+	metrics.ConsumerLatency = prometheus.NewHistogram(prometheus.HistogramOpts{Name: "consumer_latency"})
+	reporter := errreport.NewNoop()
+	client := &Client{logger: zap.NewNop(), metrics: metrics, reporter: reporter}
+
+	value := func(id, eventType string) []byte {
+		b, _ := json.Marshal(map[string]string{"id": id, "event_type": eventType})
+		return b
+	}
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 3)}
+	claim.messages <- &sarama.ConsumerMessage{Topic: "app.form.updated", Partition: 1, Offset: 7, Value: value("m-7", "form.updated")}
+	claim.messages <- &sarama.ConsumerMessage{Topic: "app.form.updated", Partition: 1, Offset: 8, Value: value("m-8", "form.deleted")}
+	claim.messages <- &sarama.ConsumerMessage{Topic: "app.form.updated", Partition: 1, Offset: 9, Value: value("m-9", "form.updated"),
+		Headers: []*sarama.RecordHeader{{Key: []byte(envelope.AlgorithmHeader), Value: []byte("AES-256-GCM")}}}
+	close(claim.messages)
+
+	session := &fakeSession{ctx: context.Background()}
+	handler := &consumerGroupHandler{client: client, handler: failingHandler{}, logger: zap.NewNop()}
+	if err := handler.ConsumeClaim(session, claim); err != nil {
+		t.Fatal(err)
+	}
+
+	events := reporter.Events()
+	if len(events) != 2 {
+		t.Fatalf("reported %d events, want the failures of offsets 8 and 9", len(events))
+	}
+	handled := events[0].Message
+	if handled == nil || handled.ID != "m-8" || handled.EventType != "form.deleted" || handled.Topic != "app.form.updated" ||
+		handled.Partition != 1 || handled.Offset != 8 || handled.Group != "event-bus" {
+		t.Errorf("handling failure reported with %+v", handled)
+	}
+	if decrypted := events[1]; !errors.Is(decrypted.Err, ErrEncryptionNotConfigured) || decrypted.Message.Offset != 9 {
+		t.Errorf("decryption failure reported as %+v", decrypted)
+	}
+}
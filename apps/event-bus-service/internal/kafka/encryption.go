@@ -0,0 +1,123 @@
+package kafka
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/debezium"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/envelope"
+)
+
+// ErrEncryptionNotConfigured is returned for an encrypted message consumed
+// without the keys of kafka.encryption
+var ErrEncryptionNotConfigured = errors.New("message is encrypted but encryption is not configured")
+
+// newEnvelope creates the envelope of the KMS of cfg
+func newEnvelope(ctx context.Context, cfg config.KafkaEncryptionConfig) (*envelope.Envelope, error) {
+	var kms envelope.KMS
+	switch cfg.KMS {
+	case "aws":
+		awsKMS, err := envelope.NewAWSKMS(envelope.AWSConfig{
+			Region:          cfg.AWS.Region,
+			KeyID:           cfg.AWS.KeyID,
+			Endpoint:        cfg.AWS.Endpoint,
+			AccessKeyID:     cfg.AWS.AccessKeyID,
+			SecretAccessKey: cfg.AWS.SecretAccessKey,
+			SessionToken:    cfg.AWS.SessionToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+		kms = awsKMS
+	default:
+		resolver, err := debezium.NewSecretResolver(cfg.Local.Secrets)
+		if err != nil {
+			return nil, err
+		}
+		localKMS, err := envelope.LoadLocalKMS(ctx, resolver, cfg.Local.SecretPrefix, cfg.Local.ActiveKey, cfg.Local.Keys)
+		if err != nil {
+			return nil, err
+		}
+		kms = localKMS
+	}
+	return envelope.New(kms, cfg.DataKeyTTL), nil
+}
+
+// encryptMessage returns a copy of message with its data encrypted, as a
+// base64 string bound to the ID of the message, and the headers of its data
+// key
+func (c *Client) encryptMessage(ctx context.Context, message *Message) (*Message, error) {
+	start := time.Now()
+	plaintext, err := json.Marshal(message.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize data: %w", err)
+	}
+	ciphertext, headers, err := c.envelope.Seal(ctx, plaintext, []byte(message.ID))
+	c.metrics.EncryptionLatency.WithLabelValues("encrypt").Observe(time.Since(start).Seconds())
+	if err != nil {
+		c.metrics.EncryptionErrors.WithLabelValues("encrypt").Inc()
+		return nil, err
+	}
+
+	encrypted := *message
+	encrypted.Data = base64.StdEncoding.EncodeToString(ciphertext)
+	encrypted.Headers = make(map[string]string, len(message.Headers)+len(headers))
+	for k, v := range message.Headers {
+		encrypted.Headers[k] = v
+	}
+	for k, v := range headers {
+		encrypted.Headers[k] = v
+	}
+	return &encrypted, nil
+}
+
+// decryptMessage replaces the data of an encrypted message with its
+// plaintext and drops the encryption headers, so a message published again
+// is not taken for encrypted. Other messages are left as they are.
+func (c *Client) decryptMessage(ctx context.Context, message *Message) error {
+	if !envelope.Encrypted(message.Headers) {
+		return nil
+	}
+	if c.envelope == nil {
+		return ErrEncryptionNotConfigured
+	}
+
+	start := time.Now()
+	err := c.openMessage(ctx, message)
+	c.metrics.EncryptionLatency.WithLabelValues("decrypt").Observe(time.Since(start).Seconds())
+	if err != nil {
+		c.metrics.EncryptionErrors.WithLabelValues("decrypt").Inc()
+		return fmt.Errorf("failed to decrypt message %s: %w", message.ID, err)
+	}
+	return nil
+}
+
+func (c *Client) openMessage(ctx context.Context, message *Message) error {
+	encoded, ok := message.Data.(string)
+	if !ok {
+		return fmt.Errorf("%w: data is not a string", envelope.ErrInvalidCiphertext)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("%w: data is not base64", envelope.ErrInvalidCiphertext)
+	}
+	plaintext, err := c.envelope.Open(ctx, message.Headers, ciphertext, []byte(message.ID))
+	if err != nil {
+		return err
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(plaintext, &data); err != nil {
+		return fmt.Errorf("failed to decode data: %w", err)
+	}
+	message.Data = data
+	delete(message.Headers, envelope.AlgorithmHeader)
+	delete(message.Headers, envelope.KeyIDHeader)
+	delete(message.Headers, envelope.DataKeyHeader)
+	return nil
+}
@@ -0,0 +1,136 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/envelope"
+)
+
+// capturingProducer keeps the messages sent
+type capturingProducer struct {
+	sarama.SyncProducer
+	sent []*sarama.ProducerMessage
+}
+
+func (p *capturingProducer) SendMessage(message *sarama.ProducerMessage) (int32, int64, error) {
+	p.sent = append(p.sent, message)
+	return 0, int64(len(p.sent) - 1), nil
+}
+
+// consumed returns the message a consumer reads for a produced message
+func consumed(t *testing.T, produced *sarama.ProducerMessage) *sarama.ConsumerMessage {
+	t.Helper()
+	value, err := produced.Value.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := &sarama.ConsumerMessage{Topic: produced.Topic, Value: value}
+	for _, header := range produced.Headers {
+		h := header
+		message.Headers = append(message.Headers, &h)
+	}
+	return message
+}
+
+func TestEncryptedTopicRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	kms, err := envelope.NewLocalKMS("k1", map[string][]byte{"k1": key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := producerTestConfig("none", 1000000)
+	cfg.Kafka.Topics.Specs = []config.TopicSpec{{Name: "app.form.published", Encrypted: true}}
+	producer := &capturingProducer{}
+	client := &Client{
+		config:        cfg,
+		logger:        zap.NewNop(),
+		producer:      producer,
+		recordVersion: 2,
+		metrics:       testMetrics(),
+		envelope:      envelope.New(kms, time.Hour),
+	}
+
+	original := testMessage()
+	original.Partition = -1
+	original.Headers = map[string]string{"tenant": "acme"}
+	if err := client.PublishMessage(context.Background(), original); err != nil {
+		t.Fatal(err)
+	}
+	if original.Data.(map[string]interface{})["form_id"] != "form-42" || len(original.Headers) != 1 {
+		t.Errorf("publishing altered the message of the caller: %+v", original)
+	}
+	if len(producer.sent) != 1 {
+		t.Fatalf("sent %d messages, want 1", len(producer.sent))
+	}
+	value, _ := producer.sent[0].Value.Encode()
+	if bytes.Contains(value, []byte("questions")) {
+		t.Errorf("produced value holds the plaintext data: %s", value)
+	}
+
+	message, err := convertKafkaMessage(consumed(t, producer.sent[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !envelope.Encrypted(message.Headers) || message.Headers[envelope.KeyIDHeader] != "k1" {
+		t.Fatalf("headers = %v, want the encryption headers", message.Headers)
+	}
+
+	// A consumer holding the keys reads the plaintext back
+	if err := client.decryptMessage(context.Background(), message); err != nil {
+		t.Fatal(err)
+	}
+	data, ok := message.Data.(map[string]interface{})
+	if !ok || data["form_id"] != "form-42" || data["count"] != 2.0 {
+		t.Errorf("decrypted data = %#v", message.Data)
+	}
+	if envelope.Encrypted(message.Headers) || message.Headers["tenant"] != "acme" {
+		t.Errorf("headers after decryption = %v, want only tenant", message.Headers)
+	}
+
+	// A consumer without them does not
+	unconfigured := &Client{metrics: testMetrics()}
+	encrypted, _ := convertKafkaMessage(consumed(t, producer.sent[0]))
+	if err := unconfigured.decryptMessage(context.Background(), encrypted); !errors.Is(err, ErrEncryptionNotConfigured) {
+		t.Errorf("decrypt without keys: err = %v, want ErrEncryptionNotConfigured", err)
+	}
+
+	// Other topics are published as they are
+	plain := testMessage()
+	plain.Topic = "app.form.updated"
+	plain.Partition = -1
+	if err := client.PublishMessage(context.Background(), plain); err != nil {
+		t.Fatal(err)
+	}
+	value, _ = producer.sent[1].Value.Encode()
+	if !bytes.Contains(value, []byte("questions")) {
+		t.Errorf("unencrypted topic value = %s, want the plaintext data", value)
+	}
+	message, _ = convertKafkaMessage(consumed(t, producer.sent[1]))
+	if err := unconfigured.decryptMessage(context.Background(), message); err != nil {
+		t.Errorf("decrypt of a plain message: %v", err)
+	}
+}
+
+func TestEncryptedTopicWithoutKeys(t *testing.T) {
+	cfg := producerTestConfig("none", 1000000)
+	cfg.Kafka.Topics.Specs = []config.TopicSpec{{Name: "app.form.published", Encrypted: true}}
+	producer := &capturingProducer{}
+	client := &Client{config: cfg, logger: zap.NewNop(), producer: producer, recordVersion: 2, metrics: testMetrics()}
+
+	message := testMessage()
+	message.Partition = -1
+	if err := client.PublishMessage(context.Background(), message); err == nil {
+		t.Error("published to an encrypted topic without keys")
+	}
+	if len(producer.sent) != 0 {
+		t.Errorf("sent %d messages in plaintext", len(producer.sent))
+	}
+}
@@ -0,0 +1,573 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+)
+
+// Clusters of the failover: the brokers are the primary
+const (
+	ClusterPrimary   = "primary"
+	ClusterSecondary = "secondary"
+)
+
+// Failover event types
+const (
+	FailoverEventFailover = "failover"
+	FailoverEventFailback = "failback"
+)
+
+// ErrSwitchingClusters is returned for messages published while the primary
+// cluster is unhealthy within the grace period of kafka.failover, refused
+// right away by the fail_fast policy or after waiting by the buffer policy.
+// It wraps ErrBackpressure, so callers retry it alike.
+var ErrSwitchingClusters = fmt.Errorf("%w: switching kafka clusters", ErrBackpressure)
+
+var (
+	// ErrFailoverDisabled is returned when switching consumers without
+	// kafka.failover
+	ErrFailoverDisabled = errors.New("kafka failover is not enabled")
+	// ErrUnknownCluster is returned when switching consumers to a cluster
+	// other than primary and secondary
+	ErrUnknownCluster = errors.New("unknown kafka cluster")
+)
+
+// FailoverStatus is the state of the failover, reported by /health
+type FailoverStatus struct {
+	// ProducerCluster is the cluster messages are published to
+	ProducerCluster string `json:"producer_cluster" example:"primary"`
+	// ConsumerCluster is the cluster consumer groups read from, only
+	// switched by hand
+	ConsumerCluster string `json:"consumer_cluster" example:"primary"`
+	// Switching is set while the primary is unhealthy within the grace
+	// period, when publishes are buffered or refused
+	Switching       bool   `json:"switching"`
+	PrimaryStatus   string `json:"primary_status" example:"healthy"`
+	SecondaryStatus string `json:"secondary_status" example:"healthy"`
+
+	PrimaryUnhealthySince *time.Time `json:"primary_unhealthy_since,omitempty"`
+	LastFailover          *time.Time `json:"last_failover,omitempty"`
+	LastFailback          *time.Time `json:"last_failback,omitempty"`
+}
+
+// FailoverEvent is the data of the events published to
+// kafka.failover.event_topic when the producer changes clusters
+type FailoverEvent struct {
+	Type string `json:"type" example:"failover"`
+	From string `json:"from" example:"primary"`
+	To   string `json:"to" example:"secondary"`
+	// Reason is the health error of the primary, on failover
+	Reason string    `json:"reason,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+// healthSource checks the health of a cluster
+type healthSource interface {
+	Health(ctx context.Context) *Health
+}
+
+// cluster is a set of brokers the client may produce to and consume from
+type cluster struct {
+	name     string
+	brokers  []string
+	producer sarama.SyncProducer
+	health   healthSource
+	// admin holds the connections of the health check of the secondary
+	admin sarama.Client
+}
+
+// FailoverMetrics counts the switches of the producer between clusters
+type FailoverMetrics struct {
+	Switches        *prometheus.CounterVec
+	ProducerCluster prometheus.Gauge
+}
+
+// NewFailoverMetrics creates the failover metrics, registered with reg
+func NewFailoverMetrics(reg prometheus.Registerer) *FailoverMetrics {
+	m := &FailoverMetrics{
+		Switches: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "kafka_failover_switches_total",
+			Help: "Total number of switches of the producer between clusters, by type (failover or failback)",
+		}, []string{"type"}),
+		ProducerCluster: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "kafka_failover_producer_cluster",
+			Help: "Cluster the producer publishes to (0 = primary, 1 = secondary)",
+		}),
+	}
+	if reg != nil {
+		reg.MustRegister(m.Switches, m.ProducerCluster)
+	}
+	return m
+}
+
+// failover moves the producer from the primary to the secondary cluster
+// while the primary is unhealthy, and back once it has recovered
+type failover struct {
+	cfg      config.KafkaFailoverConfig
+	clusters [2]*cluster
+	logger   *zap.Logger
+	metrics  *FailoverMetrics
+	now      func() time.Time
+	// emit is called with each switch of the producer, outside the lock
+	emit func(FailoverEvent)
+
+	mu              sync.Mutex
+	producer        int
+	consumer        int
+	primaryStatus   string
+	secondaryStatus string
+	unhealthySince  time.Time
+	healthySince    time.Time
+	// switching is closed when the switchover ends, nil outside of one
+	switching    chan struct{}
+	lastFailover time.Time
+	lastFailback time.Time
+}
+
+func newFailover(cfg config.KafkaFailoverConfig, primary, secondary *cluster, metrics *FailoverMetrics, logger *zap.Logger) *failover {
+	if cfg.SwitchoverTimeout <= 0 {
+		cfg.SwitchoverTimeout = cfg.GracePeriod + cfg.CheckInterval
+	}
+	return &failover{
+		cfg:      cfg,
+		clusters: [2]*cluster{primary, secondary},
+		logger:   logger,
+		metrics:  metrics,
+		now:      time.Now,
+		emit:     func(FailoverEvent) {},
+	}
+}
+
+// run evaluates the health of the clusters every check interval until ctx
+// is done
+func (f *failover) run(ctx context.Context) {
+	ticker := time.NewTicker(f.cfg.CheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			f.evaluate(ctx)
+		}
+	}
+}
+
+// evaluate checks both clusters and switches the producer when the primary
+// has been unhealthy for the grace period, or healthy again for the
+// failback delay
+func (f *failover) evaluate(ctx context.Context) {
+	primary := f.clusters[0].health.Health(ctx)
+	secondary := f.clusters[1].health.Health(ctx)
+	healthy := primary.Status == StatusHealthy
+
+	f.mu.Lock()
+	now := f.now()
+	f.primaryStatus, f.secondaryStatus = primary.Status, secondary.Status
+	var event *FailoverEvent
+	switch {
+	case f.producer == 0 && healthy:
+		f.unhealthySince = time.Time{}
+		f.endSwitchover()
+
+	case f.producer == 0:
+		if f.unhealthySince.IsZero() {
+			f.unhealthySince = now
+			f.switching = make(chan struct{})
+			f.logger.Warn("Primary Kafka cluster unhealthy, failing over after the grace period",
+				zap.String("error", primary.Error),
+				zap.Duration("grace_period", f.cfg.GracePeriod))
+		}
+		if now.Sub(f.unhealthySince) < f.cfg.GracePeriod {
+			break
+		}
+		// Publishes go on failing on the primary rather than wait for a
+		// secondary that is down too
+		f.endSwitchover()
+		if secondary.Status != StatusHealthy {
+			f.logger.Error("Primary Kafka cluster unhealthy past the grace period, but the secondary is unhealthy too",
+				zap.String("primary_error", primary.Error),
+				zap.String("secondary_error", secondary.Error))
+			break
+		}
+		f.producer = 1
+		f.unhealthySince, f.healthySince = time.Time{}, time.Time{}
+		f.lastFailover = now
+		event = &FailoverEvent{Type: FailoverEventFailover, From: ClusterPrimary, To: ClusterSecondary, Reason: primary.Error, At: now}
+
+	case healthy:
+		if f.healthySince.IsZero() {
+			f.healthySince = now
+		}
+		if now.Sub(f.healthySince) < f.cfg.FailbackAfter {
+			break
+		}
+		f.producer = 0
+		f.healthySince = time.Time{}
+		f.lastFailback = now
+		event = &FailoverEvent{Type: FailoverEventFailback, From: ClusterSecondary, To: ClusterPrimary, At: now}
+
+	default:
+		f.healthySince = time.Time{}
+	}
+	f.mu.Unlock()
+
+	if event == nil {
+		return
+	}
+	f.metrics.Switches.WithLabelValues(event.Type).Inc()
+	if event.To == ClusterPrimary {
+		f.metrics.ProducerCluster.Set(0)
+	} else {
+		f.metrics.ProducerCluster.Set(1)
+	}
+	f.logger.Warn("Kafka producer switched clusters",
+		zap.String("type", event.Type),
+		zap.String("from", event.From),
+		zap.String("to", event.To),
+		zap.String("reason", event.Reason))
+	f.emit(*event)
+}
+
+// endSwitchover releases the publishes waiting for the switchover. f.mu
+// must be held.
+func (f *failover) endSwitchover() {
+	if f.switching != nil {
+		close(f.switching)
+		f.switching = nil
+	}
+}
+
+// producerCluster returns the cluster to publish to. During a switchover it
+// waits for it to end by the buffer policy, or refuses by fail_fast.
+func (f *failover) producerCluster(ctx context.Context) (*cluster, error) {
+	f.mu.Lock()
+	switching := f.switching
+	f.mu.Unlock()
+
+	if switching != nil {
+		if f.cfg.SwitchoverPolicy == "fail_fast" {
+			return nil, ErrSwitchingClusters
+		}
+		timer := time.NewTimer(f.cfg.SwitchoverTimeout)
+		defer timer.Stop()
+		select {
+		case <-switching:
+		case <-timer.C:
+			return nil, fmt.Errorf("%w: still switching after %s", ErrSwitchingClusters, f.cfg.SwitchoverTimeout)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.clusters[f.producer], nil
+}
+
+// onPrimary reports whether messages are published to the primary; it is
+// true without failover
+func (f *failover) onPrimary() bool {
+	if f == nil {
+		return true
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.producer == 0
+}
+
+// consumerCluster returns the cluster consumer groups read from
+func (f *failover) consumerCluster() *cluster {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.clusters[f.consumer]
+}
+
+// setConsumerCluster makes consumer groups read from the named cluster
+func (f *failover) setConsumerCluster(name string) (*cluster, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, c := range f.clusters {
+		if c.name == name {
+			f.consumer = i
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownCluster, name)
+}
+
+// Status returns the state of the failover
+func (f *failover) Status() *FailoverStatus {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	status := &FailoverStatus{
+		ProducerCluster: f.clusters[f.producer].name,
+		ConsumerCluster: f.clusters[f.consumer].name,
+		Switching:       f.switching != nil,
+		PrimaryStatus:   f.primaryStatus,
+		SecondaryStatus: f.secondaryStatus,
+	}
+	if !f.unhealthySince.IsZero() {
+		since := f.unhealthySince
+		status.PrimaryUnhealthySince = &since
+	}
+	if !f.lastFailover.IsZero() {
+		at := f.lastFailover
+		status.LastFailover = &at
+	}
+	if !f.lastFailback.IsZero() {
+		at := f.lastFailback
+		status.LastFailback = &at
+	}
+	return status
+}
+
+// switchableGroup is a consumer group of the client. Switching consumers
+// reopens it on the other cluster and closes the group it replaces, so the
+// Consume loops pick up the new group.
+type switchableGroup struct {
+	groupID string
+	conf    *sarama.Config
+	onError func(error)
+
+	mu      sync.Mutex
+	group   sarama.ConsumerGroup
+	brokers []string
+
+	// Partitions the group consumes, on whichever cluster
+	assignment assignment
+}
+
+// openConsumerGroup joins the consumer group groupID on brokers. onError is
+// called with the errors of the group when conf returns them.
+func openConsumerGroup(brokers []string, groupID string, conf *sarama.Config, onError func(error)) (*switchableGroup, error) {
+	g := &switchableGroup{groupID: groupID, conf: conf, onError: onError}
+	group, err := g.open(brokers)
+	if err != nil {
+		return nil, err
+	}
+	g.group, g.brokers = group, brokers
+	return g, nil
+}
+
+func (g *switchableGroup) open(brokers []string) (sarama.ConsumerGroup, error) {
+	group, err := sarama.NewConsumerGroup(brokers, g.groupID, g.conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consumer group: %w", err)
+	}
+	if g.conf.Consumer.Return.Errors {
+		// The group blocks on errors nobody reads
+		go func() {
+			for err := range group.Errors() {
+				g.onError(err)
+			}
+		}()
+	}
+	return group, nil
+}
+
+// current returns the group to consume with
+func (g *switchableGroup) current() sarama.ConsumerGroup {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.group
+}
+
+// reopen moves the group to brokers, unless it is already on them
+func (g *switchableGroup) reopen(brokers []string) error {
+	g.mu.Lock()
+	same := sameBrokers(g.brokers, brokers)
+	g.mu.Unlock()
+	if same {
+		return nil
+	}
+
+	group, err := g.open(brokers)
+	if err != nil {
+		return err
+	}
+	g.mu.Lock()
+	previous := g.group
+	g.group, g.brokers = group, brokers
+	g.mu.Unlock()
+	if err := previous.Close(); err != nil {
+		return fmt.Errorf("failed to close consumer group %s on the previous cluster: %w", g.groupID, err)
+	}
+	return nil
+}
+
+// Close closes the group
+func (g *switchableGroup) Close() error {
+	return g.current().Close()
+}
+
+func sameBrokers(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// consumerBrokers returns the brokers consumer groups join
+func (c *Client) consumerBrokers() []string {
+	if c.failover == nil {
+		return c.config.Kafka.Brokers
+	}
+	return c.failover.consumerCluster().brokers
+}
+
+// SwitchConsumers moves the consumer groups of the client to the named
+// cluster, primary or secondary. Consumers never fail over by themselves:
+// the offsets they committed on one cluster mean nothing on the other, so
+// an operator switches them once the offsets are reconciled. Groups joined
+// later, and stream subscriptions, join the cluster consumers are on.
+func (c *Client) SwitchConsumers(ctx context.Context, name string) (*FailoverStatus, error) {
+	if c.failover == nil {
+		return nil, ErrFailoverDisabled
+	}
+	target, err := c.failover.setConsumerCluster(name)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.RLock()
+	groups := append([]*switchableGroup{c.consumer}, c.batchConsumers...)
+	c.mutex.RUnlock()
+
+	// Groups already on the target are skipped, so a partial switch is
+	// completed by switching again
+	var errs []error
+	for _, group := range groups {
+		if group == nil {
+			continue
+		}
+		if err := group.reopen(target.brokers); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return c.failover.Status(), fmt.Errorf("failed to switch consumers to the %s cluster: %w", name, err)
+	}
+
+	c.logger.Warn("Kafka consumers switched clusters",
+		zap.String("cluster", name),
+		zap.Strings("brokers", target.brokers),
+		zap.Int("groups", len(groups)))
+	return c.failover.Status(), nil
+}
+
+// publishFailoverEvent publishes a switch of the producer to the event
+// topic, on the cluster the producer switched to
+func (c *Client) publishFailoverEvent(event FailoverEvent) {
+	topic := c.config.Kafka.Failover.EventTopic
+	if topic == "" {
+		return
+	}
+	message := &Message{
+		ID:        fmt.Sprintf("kafka-%s-%d", event.Type, event.At.UnixNano()),
+		EventType: "kafka.cluster." + event.Type,
+		Source:    c.config.Kafka.ClientID,
+		Data:      event,
+		Topic:     topic,
+		Key:       event.Type,
+		Metadata: MessageMetadata{
+			Timestamp:   event.At,
+			Version:     "1.0",
+			ContentType: "application/json",
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.Kafka.Failover.CheckInterval)
+	defer cancel()
+	if err := c.PublishMessage(ctx, message); err != nil {
+		c.logger.Error("Failed to publish Kafka failover event", zap.String("type", event.Type), zap.Error(err))
+	}
+}
+
+// initFailover connects to the secondary cluster and starts evaluating the
+// health of both
+func (c *Client) initFailover(kafkaConfig *sarama.Config) error {
+	failoverConfig := c.config.Kafka.Failover
+	brokers := failoverConfig.SecondaryBrokers
+
+	producer, err := sarama.NewSyncProducer(brokers, kafkaConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create secondary producer: %w", err)
+	}
+	client, err := sarama.NewClient(brokers, kafkaConfig)
+	if err != nil {
+		producer.Close()
+		return fmt.Errorf("failed to create secondary client: %w", err)
+	}
+
+	healthConfig := c.config.Kafka.Health
+	probeConfig := *kafkaConfig
+	if healthConfig.Timeout > 0 {
+		probeConfig.Net.DialTimeout = healthConfig.Timeout
+		probeConfig.Net.ReadTimeout = healthConfig.Timeout
+		probeConfig.Net.WriteTimeout = healthConfig.Timeout
+	}
+	// The cache of the health check must not outlive a check interval
+	if healthConfig.CacheTTL > failoverConfig.CheckInterval {
+		healthConfig.CacheTTL = failoverConfig.CheckInterval
+	}
+
+	primary := &cluster{name: ClusterPrimary, brokers: c.config.Kafka.Brokers, producer: c.producer, health: c.health}
+	secondary := &cluster{
+		name:     ClusterSecondary,
+		brokers:  brokers,
+		producer: producer,
+		health:   newHealthChecker(healthConfig, brokers, &saramaProbe{client: client, producer: producer, conf: &probeConfig}),
+		admin:    client,
+	}
+	c.failover = newFailover(failoverConfig, primary, secondary, NewFailoverMetrics(prometheus.DefaultRegisterer), c.logger)
+	c.failover.emit = func(event FailoverEvent) {
+		// Published from its own goroutine: the event may wait for the
+		// in-flight limits like any other message
+		go c.publishFailoverEvent(event)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.stopFailover = cancel
+	go c.failover.run(ctx)
+
+	c.logger.Info("Kafka failover initialized successfully",
+		zap.Strings("secondary_brokers", brokers),
+		zap.Duration("grace_period", failoverConfig.GracePeriod),
+		zap.String("switchover_policy", failoverConfig.SwitchoverPolicy))
+	return nil
+}
+
+// send sends the message to the cluster published to
+func (c *Client) send(ctx context.Context, message *sarama.ProducerMessage) (int32, int64, error) {
+	if c.failover == nil {
+		return c.producer.SendMessage(message)
+	}
+	target, err := c.failover.producerCluster(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	return target.producer.SendMessage(message)
+}
+
+// FailoverStatus returns the state of the failover, nil without
+// kafka.failover
+func (c *Client) FailoverStatus() *FailoverStatus {
+	if c.failover == nil {
+		return nil
+	}
+	return c.failover.Status()
+}
@@ -0,0 +1,271 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+)
+
+// fakeCluster is the producer and health of a cluster that can be killed
+// and revived. Methods of the producer it does not override panic.
+type fakeCluster struct {
+	sarama.SyncProducer
+	mu   sync.Mutex
+	down bool
+	sent int
+}
+
+func (c *fakeCluster) SendMessage(*sarama.ProducerMessage) (int32, int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.down {
+		return 0, 0, sarama.ErrOutOfBrokers
+	}
+	c.sent++
+	return 0, int64(c.sent), nil
+}
+
+func (c *fakeCluster) Health(context.Context) *Health {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.down {
+		return &Health{Status: StatusUnhealthy, Error: "0 of 3 brokers reachable, 1 required", CheckedAt: time.Now()}
+	}
+	return &Health{Status: StatusHealthy, HealthyBrokers: 3, CheckedAt: time.Now()}
+}
+
+func (c *fakeCluster) setDown(down bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.down = down
+}
+
+func (c *fakeCluster) sends() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sent
+}
+
+// failoverTestClient returns a client publishing to primary, failing over
+// to secondary, with the events of the failover sent on the channel
+func failoverTestClient(cfg config.KafkaFailoverConfig, primary, secondary *fakeCluster) (*Client, chan FailoverEvent) {
+	cfg.Enabled = true
+	cfg.SecondaryBrokers = []string{"secondary:9092"}
+	conf := producerTestConfig("none", 1000000)
+	conf.Kafka.Brokers = []string{"primary:9092"}
+	conf.Kafka.Failover = cfg
+
+	events := make(chan FailoverEvent, 10)
+	f := newFailover(cfg,
+		&cluster{name: ClusterPrimary, brokers: conf.Kafka.Brokers, producer: primary, health: primary},
+		&cluster{name: ClusterSecondary, brokers: cfg.SecondaryBrokers, producer: secondary, health: secondary},
+		NewFailoverMetrics(nil), zap.NewNop())
+	f.emit = func(event FailoverEvent) { events <- event }
+
+	return &Client{
+		config:        conf,
+		logger:        zap.NewNop(),
+		producer:      primary,
+		recordVersion: 2,
+		inFlight:      inFlight{maxMessages: 100},
+		metrics:       testMetrics(),
+		failover:      f,
+	}, events
+}
+
+// TestFailoverMidStream kills the primary while messages are published one
+// after the other and checks they go on on the secondary within the grace
+// period, none lost on the way, then return to the revived primary
+func TestFailoverMidStream(t *testing.T) {
+	const (
+		interval = 10 * time.Millisecond
+		grace    = 100 * time.Millisecond
+		failback = 150 * time.Millisecond
+	)
+	primary, secondary := &fakeCluster{}, &fakeCluster{}
+	client, events := failoverTestClient(config.KafkaFailoverConfig{
+		CheckInterval:    interval,
+		GracePeriod:      grace,
+		FailbackAfter:    failback,
+		SwitchoverPolicy: "buffer",
+	}, primary, secondary)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go client.failover.run(ctx)
+
+	var (
+		published, failed int
+		killedAt          time.Time
+		deadline          = time.Now().Add(5 * time.Second)
+	)
+	for secondary.sends() < 20 {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d messages on the secondary after the primary died, want 20", secondary.sends())
+		}
+		if published == 20 && killedAt.IsZero() {
+			primary.setDown(true)
+			killedAt = time.Now()
+		}
+		// Messages sent before the death is noticed fail; none is
+		// dropped without an error
+		if err := client.PublishMessage(ctx, testMessage()); err != nil {
+			failed++
+			if secondary.sends() > 0 {
+				t.Fatalf("publish failed after the failover: %v", err)
+			}
+			continue
+		}
+		published++
+		if secondary.sends() == 1 {
+			if took := time.Since(killedAt); took > grace+5*interval+100*time.Millisecond {
+				t.Errorf("first message on the secondary %s after the primary died, want within the grace period of %s", took, grace)
+			}
+		}
+	}
+
+	if got := primary.sends() + secondary.sends(); got != published {
+		t.Errorf("%d messages sent to the clusters, %d published", got, published)
+	}
+	if got := testutil.ToFloat64(client.metrics.ProducerErrors); int(got) != failed {
+		t.Errorf("producer errors = %v, want the %d failed publishes", got, failed)
+	}
+
+	select {
+	case event := <-events:
+		if event.Type != FailoverEventFailover || event.From != ClusterPrimary || event.To != ClusterSecondary || event.Reason == "" {
+			t.Errorf("event = %+v, want a failover from the primary with its error", event)
+		}
+	default:
+		t.Fatal("no failover event")
+	}
+	if got := testutil.ToFloat64(client.failover.metrics.Switches.WithLabelValues(FailoverEventFailover)); got != 1 {
+		t.Errorf("failover switches = %v, want 1", got)
+	}
+	status := client.failover.Status()
+	if status.ProducerCluster != ClusterSecondary || status.ConsumerCluster != ClusterPrimary || status.LastFailover == nil {
+		t.Errorf("status = %+v, want the producer alone on the secondary", status)
+	}
+
+	// The revived primary takes the messages back after failback_after
+	primary.setDown(false)
+	select {
+	case event := <-events:
+		if event.Type != FailoverEventFailback || event.To != ClusterPrimary {
+			t.Errorf("event = %+v, want a failback to the primary", event)
+		}
+	case <-time.After(failback + time.Second):
+		t.Fatal("no failback to the revived primary")
+	}
+	before := primary.sends()
+	if err := client.PublishMessage(ctx, testMessage()); err != nil {
+		t.Fatal(err)
+	}
+	if primary.sends() != before+1 {
+		t.Error("message after the failback not sent to the primary")
+	}
+}
+
+func TestFailoverSwitchover(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	cfg := config.KafkaFailoverConfig{
+		CheckInterval:    time.Second,
+		GracePeriod:      30 * time.Second,
+		FailbackAfter:    time.Minute,
+		SwitchoverPolicy: "fail_fast",
+	}
+	ctx := context.Background()
+
+	t.Run("fail fast", func(t *testing.T) {
+		primary, secondary := &fakeCluster{}, &fakeCluster{}
+		client, _ := failoverTestClient(cfg, primary, secondary)
+		client.failover.now = func() time.Time { return now }
+
+		primary.setDown(true)
+		client.failover.evaluate(ctx)
+		err := client.PublishMessage(ctx, testMessage())
+		if !errors.Is(err, ErrSwitchingClusters) || !errors.Is(err, ErrBackpressure) {
+			t.Fatalf("publish during the switchover: err = %v, want ErrSwitchingClusters", err)
+		}
+		if got := testutil.ToFloat64(client.metrics.BackpressureRejections); got != 1 {
+			t.Errorf("backpressure rejections = %v, want 1", got)
+		}
+		if !client.failover.Status().Switching {
+			t.Error("status not switching within the grace period")
+		}
+
+		client.failover.now = func() time.Time { return now.Add(cfg.GracePeriod) }
+		client.failover.evaluate(ctx)
+		if err := client.PublishMessage(ctx, testMessage()); err != nil || secondary.sends() != 1 {
+			t.Errorf("publish after the grace period: err = %v, %d sent to the secondary", err, secondary.sends())
+		}
+	})
+
+	t.Run("buffer times out", func(t *testing.T) {
+		cfg := cfg
+		cfg.SwitchoverPolicy = "buffer"
+		cfg.SwitchoverTimeout = 20 * time.Millisecond
+		primary, secondary := &fakeCluster{}, &fakeCluster{}
+		client, _ := failoverTestClient(cfg, primary, secondary)
+		client.failover.now = func() time.Time { return now }
+
+		primary.setDown(true)
+		client.failover.evaluate(ctx)
+		if err := client.PublishMessage(ctx, testMessage()); !errors.Is(err, ErrSwitchingClusters) {
+			t.Errorf("publish buffered past the switchover timeout: err = %v, want ErrSwitchingClusters", err)
+		}
+	})
+
+	t.Run("secondary down too", func(t *testing.T) {
+		primary, secondary := &fakeCluster{}, &fakeCluster{}
+		client, events := failoverTestClient(cfg, primary, secondary)
+		client.failover.now = func() time.Time { return now.Add(cfg.GracePeriod) }
+		client.failover.unhealthySince = now
+
+		primary.setDown(true)
+		secondary.setDown(true)
+		client.failover.evaluate(ctx)
+		status := client.failover.Status()
+		if status.ProducerCluster != ClusterPrimary || status.Switching {
+			t.Errorf("status = %+v, want the producer left on the primary", status)
+		}
+		if len(events) != 0 {
+			t.Errorf("%d events, want none", len(events))
+		}
+		// Publishes fail on the primary rather than wait
+		if err := client.PublishMessage(ctx, testMessage()); err == nil || errors.Is(err, ErrBackpressure) {
+			t.Errorf("publish: err = %v, want the error of the primary", err)
+		}
+	})
+}
+
+func TestSwitchConsumers(t *testing.T) {
+	client, _ := failoverTestClient(config.KafkaFailoverConfig{CheckInterval: time.Second, GracePeriod: time.Second}, &fakeCluster{}, &fakeCluster{})
+
+	if _, err := client.SwitchConsumers(context.Background(), "tertiary"); !errors.Is(err, ErrUnknownCluster) {
+		t.Errorf("unknown cluster: err = %v, want ErrUnknownCluster", err)
+	}
+	status, err := client.SwitchConsumers(context.Background(), ClusterSecondary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.ConsumerCluster != ClusterSecondary || status.ProducerCluster != ClusterPrimary {
+		t.Errorf("status = %+v, want the consumers alone on the secondary", status)
+	}
+	if brokers := client.consumerBrokers(); len(brokers) != 1 || brokers[0] != "secondary:9092" {
+		t.Errorf("consumers join %v, want the secondary brokers", brokers)
+	}
+
+	disabled := &Client{config: producerTestConfig("none", 1000000)}
+	if _, err := disabled.SwitchConsumers(context.Background(), ClusterSecondary); !errors.Is(err, ErrFailoverDisabled) {
+		t.Errorf("without failover: err = %v, want ErrFailoverDisabled", err)
+	}
+}
@@ -0,0 +1,532 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrConsumerGroupNotFound is returned for groups unknown to the cluster
+	ErrConsumerGroupNotFound = errors.New("consumer group not found")
+	// ErrConsumerGroupActive is returned when resetting the offsets of a
+	// group that has members, without forcing it
+	ErrConsumerGroupActive = errors.New("consumer group has active members")
+	// ErrInvalidOffsetReset is returned for offset resets that can't be run
+	ErrInvalidOffsetReset = errors.New("invalid offset reset")
+)
+
+// Offset reset strategies
+const (
+	// ResetEarliest moves the group to the oldest retained message
+	ResetEarliest = "earliest"
+	// ResetLatest moves the group past the last message, skipping the backlog
+	ResetLatest = "latest"
+	// ResetTimestamp moves the group to the first message at or after a time
+	ResetTimestamp = "timestamp"
+	// ResetOffsets moves the group to the given offsets
+	ResetOffsets = "offsets"
+)
+
+// ConsumerGroup describes a consumer group and its members
+type ConsumerGroup struct {
+	GroupID      string        `json:"group_id"`
+	State        string        `json:"state" example:"Stable"`
+	ProtocolType string        `json:"protocol_type" example:"consumer"`
+	Members      []GroupMember `json:"members"`
+}
+
+// GroupMember is a member of a consumer group and the partitions it is
+// assigned
+type GroupMember struct {
+	MemberID string `json:"member_id"`
+	// InstanceID is the static membership ID of the member, if any
+	InstanceID string `json:"instance_id,omitempty"`
+	ClientID   string `json:"client_id"`
+	ClientHost string `json:"client_host"`
+	// Assignment maps topics to the partitions assigned to the member
+	Assignment map[string][]int32 `json:"assignment"`
+}
+
+// GroupOffsets are the committed and end offsets of the partitions a group
+// consumes
+type GroupOffsets struct {
+	GroupID    string            `json:"group_id"`
+	Partitions []PartitionOffset `json:"partitions"`
+	// TotalLag sums the lag of the partitions with a committed offset
+	TotalLag int64 `json:"total_lag"`
+}
+
+// PartitionOffset is the position of a group on a partition
+type PartitionOffset struct {
+	Topic     string `json:"topic"`
+	Partition int32  `json:"partition"`
+	// Committed is the offset of the next message the group consumes, -1
+	// when it committed none
+	Committed int64 `json:"committed"`
+	// End is the offset the next message published will get
+	End int64 `json:"end"`
+	// Lag is the number of messages left to consume, null without a
+	// committed offset
+	Lag *int64 `json:"lag"`
+}
+
+// OffsetReset is a request to move the committed offsets of a group
+type OffsetReset struct {
+	Strategy string `json:"strategy" enums:"earliest,latest,timestamp,offsets"`
+	// Topics are the topics reset by the earliest, latest and timestamp
+	// strategies, every partition of each. They default to the topics the
+	// group committed offsets on.
+	Topics []string `json:"topics,omitempty"`
+	// Timestamp is where the timestamp strategy resets to
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+	// Offsets maps topics to partitions to the offsets the offsets strategy
+	// resets to. Offsets outside the retained messages are moved to the
+	// nearest one.
+	Offsets map[string]map[int32]int64 `json:"offsets,omitempty"`
+	// Force resets groups with active members. Kafka rejects offsets
+	// committed for members still in the group, so it is meant for members
+	// that left without the coordinator noticing yet.
+	Force bool `json:"force,omitempty"`
+}
+
+// OffsetResetResult lists the offsets a group was moved from and to
+type OffsetResetResult struct {
+	GroupID    string           `json:"group_id"`
+	Strategy   string           `json:"strategy"`
+	Forced     bool             `json:"forced"`
+	Partitions []PartitionReset `json:"partitions"`
+}
+
+// PartitionReset is the reset of a partition. Previous is -1 when the group
+// had committed no offset on it.
+type PartitionReset struct {
+	Topic     string `json:"topic"`
+	Partition int32  `json:"partition"`
+	Previous  int64  `json:"previous"`
+	Offset    int64  `json:"offset"`
+}
+
+// groupOffsets looks up the offsets of partitions and commits the offsets
+// of groups. The cluster admin does not, so it is implemented with the
+// client the admin is created from.
+type groupOffsets interface {
+	Partitions(topic string) ([]int32, error)
+	// GetOffset returns the offset of the first message at or after time,
+	// in milliseconds, or sarama.OffsetOldest or sarama.OffsetNewest
+	GetOffset(topic string, partition int32, time int64) (int64, error)
+	CommitOffsets(group string, offsets map[string]map[int32]int64) error
+}
+
+// saramaOffsets implements groupOffsets with a sarama client
+type saramaOffsets struct {
+	sarama.Client
+}
+
+// CommitOffsets commits offsets for the group outside any generation, which
+// the coordinator only accepts while the group has no members
+func (o saramaOffsets) CommitOffsets(group string, offsets map[string]map[int32]int64) error {
+	coordinator, err := o.Coordinator(group)
+	if err != nil {
+		return fmt.Errorf("failed to find the coordinator of group %s: %w", group, err)
+	}
+
+	req := &sarama.OffsetCommitRequest{
+		ConsumerGroup:           group,
+		ConsumerGroupGeneration: -1,
+		RetentionTime:           -1,
+		Version:                 2,
+	}
+	for topic, partitions := range offsets {
+		for partition, offset := range partitions {
+			req.AddBlock(topic, partition, offset, 0, "")
+		}
+	}
+	resp, err := coordinator.CommitOffset(req)
+	if err != nil {
+		return fmt.Errorf("failed to commit the offsets of group %s: %w", group, err)
+	}
+
+	var errs []error
+	for topic, partitions := range resp.Errors {
+		for partition, kerr := range partitions {
+			if kerr != sarama.ErrNoError {
+				errs = append(errs, fmt.Errorf("%s/%d: %w", topic, partition, kerr))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to commit the offsets of group %s: %w", group, errors.Join(errs...))
+	}
+	return nil
+}
+
+// ListConsumerGroups returns the consumer groups of the cluster with their
+// members, sorted by ID
+func (c *Client) ListConsumerGroups(ctx context.Context) ([]ConsumerGroup, error) {
+	if c.closed {
+		return nil, fmt.Errorf("kafka client is closed")
+	}
+
+	listed, err := c.admin.ListConsumerGroups()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list consumer groups: %w", err)
+	}
+	if len(listed) == 0 {
+		return []ConsumerGroup{}, nil
+	}
+	ids := make([]string, 0, len(listed))
+	for id := range listed {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	descriptions, err := c.admin.DescribeConsumerGroups(ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe consumer groups: %w", err)
+	}
+	groups := make([]ConsumerGroup, 0, len(descriptions))
+	for _, description := range descriptions {
+		groups = append(groups, consumerGroup(description))
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].GroupID < groups[j].GroupID })
+	return groups, nil
+}
+
+// GetConsumerGroupOffsets returns the committed and end offsets of the
+// partitions of the topics a group committed offsets on or is assigned
+func (c *Client) GetConsumerGroupOffsets(ctx context.Context, groupID string) (*GroupOffsets, error) {
+	if c.closed {
+		return nil, fmt.Errorf("kafka client is closed")
+	}
+
+	group, err := c.describeGroup(groupID)
+	if err != nil {
+		return nil, err
+	}
+	committed, err := c.committedOffsets(groupID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	topics := make(map[string]bool)
+	for topic := range committed {
+		topics[topic] = true
+	}
+	for _, member := range group.Members {
+		for topic := range member.Assignment {
+			topics[topic] = true
+		}
+	}
+	partitions, err := c.partitionsOf(sortedKeys(topics))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &GroupOffsets{GroupID: groupID, Partitions: []PartitionOffset{}}
+	for _, tp := range partitions {
+		end, err := c.offsets.GetOffset(tp.topic, tp.partition, sarama.OffsetNewest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the end offset of %s/%d: %w", tp.topic, tp.partition, err)
+		}
+		position := PartitionOffset{Topic: tp.topic, Partition: tp.partition, Committed: -1, End: end}
+		if offset, ok := committed[tp.topic][tp.partition]; ok {
+			position.Committed = offset
+			lag := end - offset
+			if lag < 0 {
+				lag = 0
+			}
+			position.Lag = &lag
+			result.TotalLag += lag
+		}
+		result.Partitions = append(result.Partitions, position)
+	}
+	return result, nil
+}
+
+// ResetConsumerGroupOffsets moves the committed offsets of a group. Groups
+// with members are refused unless the reset is forced, since members would
+// overwrite the offsets with their own commits.
+func (c *Client) ResetConsumerGroupOffsets(ctx context.Context, groupID string, reset OffsetReset) (*OffsetResetResult, error) {
+	if c.closed {
+		return nil, fmt.Errorf("kafka client is closed")
+	}
+	if err := reset.validate(); err != nil {
+		return nil, err
+	}
+
+	group, err := c.describeGroup(groupID)
+	if err != nil {
+		return nil, err
+	}
+	if len(group.Members) > 0 && !reset.Force {
+		return nil, fmt.Errorf("%w: %s has %d members; stop its consumers or force the reset", ErrConsumerGroupActive, groupID, len(group.Members))
+	}
+	committed, err := c.committedOffsets(groupID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var partitions []topicPartition
+	if reset.Strategy == ResetOffsets {
+		if partitions, err = c.requestedPartitions(reset.Offsets); err != nil {
+			return nil, err
+		}
+	} else {
+		topics := reset.Topics
+		if len(topics) == 0 {
+			topics = sortedKeys(committed)
+		}
+		if len(topics) == 0 {
+			return nil, fmt.Errorf("%w: group %s committed no offsets; name the topics to reset", ErrInvalidOffsetReset, groupID)
+		}
+		if partitions, err = c.partitionsOf(topics); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &OffsetResetResult{GroupID: groupID, Strategy: reset.Strategy, Forced: len(group.Members) > 0, Partitions: []PartitionReset{}}
+	offsets := make(map[string]map[int32]int64)
+	for _, tp := range partitions {
+		offset, err := c.resetOffset(tp, reset)
+		if err != nil {
+			return nil, err
+		}
+		previous, ok := committed[tp.topic][tp.partition]
+		if !ok {
+			previous = -1
+		}
+		if offsets[tp.topic] == nil {
+			offsets[tp.topic] = make(map[int32]int64)
+		}
+		offsets[tp.topic][tp.partition] = offset
+		result.Partitions = append(result.Partitions, PartitionReset{Topic: tp.topic, Partition: tp.partition, Previous: previous, Offset: offset})
+	}
+
+	if err := c.offsets.CommitOffsets(groupID, offsets); err != nil {
+		return nil, err
+	}
+	c.logger.Info("Consumer group offsets reset",
+		zap.String("group_id", groupID),
+		zap.String("strategy", reset.Strategy),
+		zap.Bool("forced", result.Forced),
+		zap.Int("partitions", len(result.Partitions)))
+	return result, nil
+}
+
+// resetOffset computes the offset a partition is reset to. Offsets are kept
+// within the retained messages: a timestamp after the last message resets
+// to the end, like the latest strategy, and requested offsets outside the
+// log move to its nearest bound.
+func (c *Client) resetOffset(tp topicPartition, reset OffsetReset) (int64, error) {
+	get := func(at int64) (int64, error) {
+		offset, err := c.offsets.GetOffset(tp.topic, tp.partition, at)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get the offsets of %s/%d: %w", tp.topic, tp.partition, err)
+		}
+		return offset, nil
+	}
+
+	switch reset.Strategy {
+	case ResetEarliest:
+		return get(sarama.OffsetOldest)
+	case ResetLatest:
+		return get(sarama.OffsetNewest)
+	case ResetTimestamp:
+		offset, err := get(reset.Timestamp.UnixMilli())
+		if err != nil {
+			return 0, err
+		}
+		// Kafka answers -1 when no message is at or after the timestamp
+		if offset < 0 {
+			return get(sarama.OffsetNewest)
+		}
+		return offset, nil
+	default:
+		earliest, err := get(sarama.OffsetOldest)
+		if err != nil {
+			return 0, err
+		}
+		end, err := get(sarama.OffsetNewest)
+		if err != nil {
+			return 0, err
+		}
+		offset := reset.Offsets[tp.topic][tp.partition]
+		if offset < earliest {
+			return earliest, nil
+		}
+		if offset > end {
+			return end, nil
+		}
+		return offset, nil
+	}
+}
+
+// validate checks that the reset carries what its strategy needs
+func (r OffsetReset) validate() error {
+	switch r.Strategy {
+	case ResetEarliest, ResetLatest:
+	case ResetTimestamp:
+		// Offsets are looked up by milliseconds since the epoch; earlier
+		// times would collide with the -1 and -2 sentinels of the protocol
+		if r.Timestamp == nil || r.Timestamp.UnixMilli() < 0 {
+			return fmt.Errorf("%w: the timestamp strategy needs a timestamp after 1970", ErrInvalidOffsetReset)
+		}
+	case ResetOffsets:
+		if len(r.Offsets) == 0 {
+			return fmt.Errorf("%w: the offsets strategy needs offsets", ErrInvalidOffsetReset)
+		}
+		if len(r.Topics) > 0 {
+			return fmt.Errorf("%w: the offsets strategy takes its topics from offsets", ErrInvalidOffsetReset)
+		}
+	default:
+		return fmt.Errorf("%w: strategy %q is not one of earliest, latest, timestamp or offsets", ErrInvalidOffsetReset, r.Strategy)
+	}
+	return nil
+}
+
+// describeGroup describes a group, which must exist
+func (c *Client) describeGroup(groupID string) (*ConsumerGroup, error) {
+	descriptions, err := c.admin.DescribeConsumerGroups([]string{groupID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe consumer group %s: %w", groupID, err)
+	}
+	for _, description := range descriptions {
+		if description.GroupId != groupID {
+			continue
+		}
+		switch {
+		case description.Err == sarama.ErrGroupIDNotFound, description.State == "Dead":
+			return nil, fmt.Errorf("%w: %s", ErrConsumerGroupNotFound, groupID)
+		case description.Err != sarama.ErrNoError:
+			return nil, fmt.Errorf("failed to describe consumer group %s: %w", groupID, description.Err)
+		}
+		group := consumerGroup(description)
+		return &group, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrConsumerGroupNotFound, groupID)
+}
+
+// committedOffsets returns the offsets the group committed, by topic and
+// partition. A nil topicPartitions fetches every committed offset.
+func (c *Client) committedOffsets(groupID string, topicPartitions map[string][]int32) (map[string]map[int32]int64, error) {
+	resp, err := c.admin.ListConsumerGroupOffsets(groupID, topicPartitions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the offsets of consumer group %s: %w", groupID, err)
+	}
+	if resp.Err != sarama.ErrNoError {
+		return nil, fmt.Errorf("failed to list the offsets of consumer group %s: %w", groupID, resp.Err)
+	}
+
+	committed := make(map[string]map[int32]int64)
+	for topic, partitions := range resp.Blocks {
+		for partition, block := range partitions {
+			if block.Err != sarama.ErrNoError || block.Offset < 0 {
+				continue
+			}
+			if committed[topic] == nil {
+				committed[topic] = make(map[int32]int64)
+			}
+			committed[topic][partition] = block.Offset
+		}
+	}
+	return committed, nil
+}
+
+// topicPartition is a partition of a topic
+type topicPartition struct {
+	topic     string
+	partition int32
+}
+
+// partitionsOf returns every partition of the topics, in order
+func (c *Client) partitionsOf(topics []string) ([]topicPartition, error) {
+	var partitions []topicPartition
+	for _, topic := range topics {
+		ids, err := c.offsets.Partitions(topic)
+		if errors.Is(err, sarama.ErrUnknownTopicOrPartition) {
+			return nil, fmt.Errorf("%w: %s", ErrTopicNotFound, topic)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the partitions of topic %s: %w", topic, err)
+		}
+		ids = append([]int32(nil), ids...)
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		for _, id := range ids {
+			partitions = append(partitions, topicPartition{topic: topic, partition: id})
+		}
+	}
+	return partitions, nil
+}
+
+// requestedPartitions returns the partitions of the requested offsets, in
+// order, which must exist
+func (c *Client) requestedPartitions(offsets map[string]map[int32]int64) ([]topicPartition, error) {
+	existing, err := c.partitionsOf(sortedKeys(offsets))
+	if err != nil {
+		return nil, err
+	}
+	var partitions []topicPartition
+	for _, tp := range existing {
+		if _, ok := offsets[tp.topic][tp.partition]; ok {
+			partitions = append(partitions, tp)
+		}
+	}
+	if count := countOffsets(offsets); len(partitions) != count {
+		return nil, fmt.Errorf("%w: %d of the %d partitions requested do not exist", ErrInvalidOffsetReset, count-len(partitions), count)
+	}
+	return partitions, nil
+}
+
+func countOffsets(offsets map[string]map[int32]int64) int {
+	count := 0
+	for _, partitions := range offsets {
+		count += len(partitions)
+	}
+	return count
+}
+
+// consumerGroup converts the description of a group. Assignments that fail
+// to decode, such as those of groups not using the consumer protocol, are
+// left empty.
+func consumerGroup(description *sarama.GroupDescription) ConsumerGroup {
+	group := ConsumerGroup{
+		GroupID:      description.GroupId,
+		State:        description.State,
+		ProtocolType: description.ProtocolType,
+		Members:      make([]GroupMember, 0, len(description.Members)),
+	}
+	for id, member := range description.Members {
+		m := GroupMember{
+			MemberID:   id,
+			ClientID:   member.ClientId,
+			ClientHost: member.ClientHost,
+			Assignment: map[string][]int32{},
+		}
+		if member.GroupInstanceId != nil {
+			m.InstanceID = *member.GroupInstanceId
+		}
+		if assignment, err := member.GetMemberAssignment(); err == nil && assignment != nil {
+			for topic, partitions := range assignment.Topics {
+				m.Assignment[topic] = partitions
+			}
+		}
+		group.Members = append(group.Members, m)
+	}
+	sort.Slice(group.Members, func(i, j int) bool { return group.Members[i].MemberID < group.Members[j].MemberID })
+	return group
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,274 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+)
+
+// fakeGroupAdmin keeps consumer groups in memory. Methods it does not
+// override panic.
+type fakeGroupAdmin struct {
+	sarama.ClusterAdmin
+	groups    map[string]*sarama.GroupDescription
+	committed map[string]map[string]map[int32]int64
+}
+
+func (a *fakeGroupAdmin) ListConsumerGroups() (map[string]string, error) {
+	groups := make(map[string]string)
+	for id, group := range a.groups {
+		groups[id] = group.ProtocolType
+	}
+	return groups, nil
+}
+
+func (a *fakeGroupAdmin) DescribeConsumerGroups(ids []string) ([]*sarama.GroupDescription, error) {
+	var descriptions []*sarama.GroupDescription
+	for _, id := range ids {
+		group, ok := a.groups[id]
+		if !ok {
+			group = &sarama.GroupDescription{GroupId: id, State: "Dead"}
+		}
+		descriptions = append(descriptions, group)
+	}
+	return descriptions, nil
+}
+
+func (a *fakeGroupAdmin) ListConsumerGroupOffsets(group string, _ map[string][]int32) (*sarama.OffsetFetchResponse, error) {
+	resp := &sarama.OffsetFetchResponse{Blocks: make(map[string]map[int32]*sarama.OffsetFetchResponseBlock)}
+	for topic, partitions := range a.committed[group] {
+		resp.Blocks[topic] = make(map[int32]*sarama.OffsetFetchResponseBlock)
+		for partition, offset := range partitions {
+			resp.Blocks[topic][partition] = &sarama.OffsetFetchResponseBlock{Offset: offset}
+		}
+	}
+	return resp, nil
+}
+
+// fakeLog is a partition whose messages from offset earliest on have the
+// given timestamps
+type fakeLog struct {
+	earliest   int64
+	timestamps []time.Time
+}
+
+// fakeOffsets answers offset lookups from logs, looking timestamps up the
+// way brokers do, and records commits
+type fakeOffsets struct {
+	logs    map[string][]fakeLog
+	commits map[string]map[string]map[int32]int64
+}
+
+func (o *fakeOffsets) Partitions(topic string) ([]int32, error) {
+	logs, ok := o.logs[topic]
+	if !ok {
+		return nil, sarama.ErrUnknownTopicOrPartition
+	}
+	ids := make([]int32, len(logs))
+	for i := range logs {
+		ids[i] = int32(len(logs) - 1 - i)
+	}
+	return ids, nil
+}
+
+func (o *fakeOffsets) GetOffset(topic string, partition int32, at int64) (int64, error) {
+	log := o.logs[topic][partition]
+	switch at {
+	case sarama.OffsetOldest:
+		return log.earliest, nil
+	case sarama.OffsetNewest:
+		return log.earliest + int64(len(log.timestamps)), nil
+	}
+	for i, timestamp := range log.timestamps {
+		if timestamp.UnixMilli() >= at {
+			return log.earliest + int64(i), nil
+		}
+	}
+	return -1, nil
+}
+
+func (o *fakeOffsets) CommitOffsets(group string, offsets map[string]map[int32]int64) error {
+	if o.commits == nil {
+		o.commits = make(map[string]map[string]map[int32]int64)
+	}
+	o.commits[group] = offsets
+	return nil
+}
+
+func newGroupsClient() (*Client, *fakeOffsets) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	minutes := func(n int) []time.Time {
+		timestamps := make([]time.Time, n)
+		for i := range timestamps {
+			timestamps[i] = start.Add(time.Duration(i) * time.Minute)
+		}
+		return timestamps
+	}
+	member := &sarama.GroupMemberDescription{ClientId: "event-bus", ClientHost: "/10.0.0.7"}
+	member.MemberAssignment = encodeAssignment(map[string][]int32{"form.events": {0, 1}})
+
+	admin := &fakeGroupAdmin{
+		groups: map[string]*sarama.GroupDescription{
+			"projector": {GroupId: "projector", State: "Empty", ProtocolType: "consumer"},
+			"notifier":  {GroupId: "notifier", State: "Stable", ProtocolType: "consumer", Members: map[string]*sarama.GroupMemberDescription{"m-1": member}},
+		},
+		committed: map[string]map[string]map[int32]int64{
+			"projector": {"form.events": {0: 100, 1: 30}},
+			"notifier":  {"form.events": {0: 115}},
+		},
+	}
+	// Partition 0 retains offsets 100 to 119, a message a minute from
+	// start; partition 1 offsets 0 to 59, likewise
+	offsets := &fakeOffsets{logs: map[string][]fakeLog{
+		"form.events": {{earliest: 100, timestamps: minutes(20)}, {earliest: 0, timestamps: minutes(60)}},
+	}}
+	return &Client{admin: admin, offsets: offsets, logger: zap.NewNop()}, offsets
+}
+
+// encodeAssignment encodes a version 0 member assignment of the consumer
+// protocol, which sarama only decodes
+func encodeAssignment(topics map[string][]int32) []byte {
+	var buf bytes.Buffer
+	write := func(v interface{}) { _ = binary.Write(&buf, binary.BigEndian, v) }
+	write(int16(0))
+	write(int32(len(topics)))
+	for topic, partitions := range topics {
+		write(int16(len(topic)))
+		buf.WriteString(topic)
+		write(int32(len(partitions)))
+		for _, partition := range partitions {
+			write(partition)
+		}
+	}
+	// No user data
+	write(int32(-1))
+	return buf.Bytes()
+}
+
+func TestListConsumerGroups(t *testing.T) {
+	c, _ := newGroupsClient()
+	groups, err := c.ListConsumerGroups(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 2 || groups[0].GroupID != "notifier" || groups[1].GroupID != "projector" {
+		t.Fatalf("groups = %+v, want notifier and projector", groups)
+	}
+	members := groups[0].Members
+	if len(members) != 1 || !reflect.DeepEqual(members[0].Assignment, map[string][]int32{"form.events": {0, 1}}) {
+		t.Errorf("members = %+v, want one assigned both partitions", members)
+	}
+}
+
+func TestConsumerGroupOffsets(t *testing.T) {
+	c, _ := newGroupsClient()
+	offsets, err := c.GetConsumerGroupOffsets(context.Background(), "notifier")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Partition 1 is assigned but was never committed
+	if len(offsets.Partitions) != 2 {
+		t.Fatalf("partitions = %+v, want both", offsets.Partitions)
+	}
+	if p := offsets.Partitions[0]; p.Committed != 115 || p.End != 120 || p.Lag == nil || *p.Lag != 5 {
+		t.Errorf("partition 0 = %+v, want committed 115, end 120 and lag 5", p)
+	}
+	if p := offsets.Partitions[1]; p.Committed != -1 || p.End != 60 || p.Lag != nil {
+		t.Errorf("partition 1 = %+v, want no committed offset nor lag", p)
+	}
+	if offsets.TotalLag != 5 {
+		t.Errorf("total lag = %d, want 5", offsets.TotalLag)
+	}
+
+	if _, err := c.GetConsumerGroupOffsets(context.Background(), "unknown"); !errors.Is(err, ErrConsumerGroupNotFound) {
+		t.Errorf("unknown group: err = %v, want ErrConsumerGroupNotFound", err)
+	}
+}
+
+func TestResetConsumerGroupOffsets(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		t := start.Add(d)
+		return &t
+	}
+
+	tests := []struct {
+		name  string
+		reset OffsetReset
+		want  map[int32]int64
+	}{
+		{"earliest", OffsetReset{Strategy: ResetEarliest}, map[int32]int64{0: 100, 1: 0}},
+		{"latest", OffsetReset{Strategy: ResetLatest}, map[int32]int64{0: 120, 1: 60}},
+		// Offsets are the first message at or after the timestamp
+		{"timestamp on a message", OffsetReset{Strategy: ResetTimestamp, Timestamp: at(10 * time.Minute)}, map[int32]int64{0: 110, 1: 10}},
+		{"timestamp between messages", OffsetReset{Strategy: ResetTimestamp, Timestamp: at(10*time.Minute + time.Second)}, map[int32]int64{0: 111, 1: 11}},
+		// Partition 0 ends at minute 19: past it the group resets to the end
+		{"timestamp after the last message", OffsetReset{Strategy: ResetTimestamp, Timestamp: at(30 * time.Minute)}, map[int32]int64{0: 120, 1: 30}},
+		{"timestamp before retention", OffsetReset{Strategy: ResetTimestamp, Timestamp: at(-time.Hour)}, map[int32]int64{0: 100, 1: 0}},
+		{"offsets clamped to the log", OffsetReset{Strategy: ResetOffsets, Offsets: map[string]map[int32]int64{"form.events": {0: 5, 1: 500}}}, map[int32]int64{0: 100, 1: 60}},
+		{"offsets of one partition", OffsetReset{Strategy: ResetOffsets, Offsets: map[string]map[int32]int64{"form.events": {1: 42}}}, map[int32]int64{1: 42}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, offsets := newGroupsClient()
+			result, err := c.ResetConsumerGroupOffsets(context.Background(), "projector", tt.reset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := offsets.commits["projector"]["form.events"]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("committed %v, want %v", got, tt.want)
+			}
+			for _, p := range result.Partitions {
+				if want := map[int32]int64{0: 100, 1: 30}[p.Partition]; p.Previous != want {
+					t.Errorf("partition %d reset from %d, want %d", p.Partition, p.Previous, want)
+				}
+			}
+		})
+	}
+}
+
+func TestResetConsumerGroupOffsetsRefused(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name  string
+		group string
+		reset OffsetReset
+		want  error
+	}{
+		{"active group", "notifier", OffsetReset{Strategy: ResetEarliest}, ErrConsumerGroupActive},
+		{"unknown group", "unknown", OffsetReset{Strategy: ResetEarliest}, ErrConsumerGroupNotFound},
+		{"unknown strategy", "projector", OffsetReset{Strategy: "shift-by"}, ErrInvalidOffsetReset},
+		{"timestamp missing", "projector", OffsetReset{Strategy: ResetTimestamp}, ErrInvalidOffsetReset},
+		{"timestamp before the epoch", "projector", OffsetReset{Strategy: ResetTimestamp, Timestamp: &time.Time{}}, ErrInvalidOffsetReset},
+		{"unknown partition", "projector", OffsetReset{Strategy: ResetOffsets, Offsets: map[string]map[int32]int64{"form.events": {7: 0}}}, ErrInvalidOffsetReset},
+		{"unknown topic", "projector", OffsetReset{Strategy: ResetLatest, Topics: []string{"missing"}}, ErrTopicNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, offsets := newGroupsClient()
+			if _, err := c.ResetConsumerGroupOffsets(ctx, tt.group, tt.reset); !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+			if len(offsets.commits) != 0 {
+				t.Errorf("committed %v, want nothing", offsets.commits)
+			}
+		})
+	}
+
+	// Forced, the active group is reset
+	c, offsets := newGroupsClient()
+	result, err := c.ResetConsumerGroupOffsets(ctx, "notifier", OffsetReset{Strategy: ResetLatest, Force: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Forced || offsets.commits["notifier"]["form.events"][0] != 120 {
+		t.Errorf("forced reset = %+v, committed %v", result, offsets.commits)
+	}
+}
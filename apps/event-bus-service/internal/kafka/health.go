@@ -0,0 +1,302 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+)
+
+// Health statuses
+const (
+	StatusHealthy   = "healthy"
+	StatusUnhealthy = "unhealthy"
+	StatusUp        = "up"
+	StatusDown      = "down"
+)
+
+// Health is the outcome of a health check of the cluster
+type Health struct {
+	Status string `json:"status" example:"healthy"`
+	// Error lists the problems of an unhealthy cluster
+	Error          string         `json:"error,omitempty"`
+	Brokers        []BrokerHealth `json:"brokers"`
+	HealthyBrokers int            `json:"healthy_brokers"`
+	Topics         []TopicHealth  `json:"topics,omitempty"`
+	Canary         *CanaryHealth  `json:"canary,omitempty"`
+	CheckedAt      time.Time      `json:"checked_at"`
+	// Failover is the state of the failover to the secondary cluster, when
+	// enabled; the rest is then the health of the cluster published to
+	Failover *FailoverStatus `json:"failover,omitempty"`
+}
+
+// BrokerHealth is the reachability of a broker. ID is -1 for bootstrap
+// brokers of a cluster whose metadata was never read.
+type BrokerHealth struct {
+	ID     int32  `json:"id"`
+	Addr   string `json:"addr"`
+	Status string `json:"status" example:"up"`
+	Error  string `json:"error,omitempty"`
+}
+
+// TopicHealth is the leadership of the partitions of a critical topic
+type TopicHealth struct {
+	Topic      string  `json:"topic"`
+	Status     string  `json:"status" example:"healthy"`
+	Partitions int     `json:"partitions"`
+	Leaderless []int32 `json:"leaderless_partitions,omitempty"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// CanaryHealth is the outcome of producing a message and consuming it back
+type CanaryHealth struct {
+	Topic     string  `json:"topic"`
+	Status    string  `json:"status" example:"healthy"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// clusterProbe reads the state of the cluster for health checks
+type clusterProbe interface {
+	// Refresh reloads the metadata of the brokers and of topics
+	Refresh(topics []string) error
+	// Brokers returns the brokers known from the metadata
+	Brokers() []BrokerHealth
+	// Ping opens a new connection to a broker and sends it a request
+	Ping(addr string) error
+	// Partitions returns the partitions of topic and those without a leader
+	Partitions(topic string) (int, []int32, error)
+	// Canary produces a message to topic and consumes it back
+	Canary(ctx context.Context, topic string) error
+}
+
+// healthChecker checks the cluster and caches the result
+type healthChecker struct {
+	cfg       config.KafkaHealthConfig
+	bootstrap []string
+	probe     clusterProbe
+	now       func() time.Time
+
+	// mu is held during checks, so concurrent probes wait for one check
+	mu   sync.Mutex
+	last *Health
+}
+
+func newHealthChecker(cfg config.KafkaHealthConfig, bootstrap []string, probe clusterProbe) *healthChecker {
+	// Checks can't go unbounded
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 2 * time.Second
+	}
+	return &healthChecker{cfg: cfg, bootstrap: bootstrap, probe: probe, now: time.Now}
+}
+
+// Health checks the cluster, or returns the last result while it is more
+// recent than the cache TTL
+func (h *healthChecker) Health(ctx context.Context) *Health {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.last != nil && h.now().Sub(h.last.CheckedAt) < h.cfg.CacheTTL {
+		return h.last
+	}
+	h.last = h.check(ctx)
+	return h.last
+}
+
+func (h *healthChecker) check(ctx context.Context) *Health {
+	health := &Health{CheckedAt: h.now()}
+	var problems []string
+
+	refreshErr := h.run(ctx, func(context.Context) error {
+		return h.probe.Refresh(h.cfg.CriticalTopics)
+	})
+
+	// Every broker is pinged on a new connection, as connections of the
+	// client stay open a while after a broker is gone
+	health.Brokers = h.probe.Brokers()
+	if len(health.Brokers) == 0 {
+		for _, addr := range h.bootstrap {
+			health.Brokers = append(health.Brokers, BrokerHealth{ID: -1, Addr: addr})
+		}
+	}
+	var wg sync.WaitGroup
+	for i := range health.Brokers {
+		wg.Add(1)
+		go func(broker *BrokerHealth) {
+			defer wg.Done()
+			if err := h.run(ctx, func(context.Context) error { return h.probe.Ping(broker.Addr) }); err != nil {
+				broker.Status = StatusDown
+				broker.Error = err.Error()
+				return
+			}
+			broker.Status = StatusUp
+		}(&health.Brokers[i])
+	}
+	wg.Wait()
+	for _, broker := range health.Brokers {
+		if broker.Status == StatusUp {
+			health.HealthyBrokers++
+		}
+	}
+	minBrokers := h.cfg.MinHealthyBrokers
+	if minBrokers < 1 {
+		minBrokers = 1
+	}
+	if health.HealthyBrokers < minBrokers {
+		problems = append(problems, fmt.Sprintf("%d of %d brokers reachable, %d required", health.HealthyBrokers, len(health.Brokers), minBrokers))
+	}
+
+	for _, topic := range h.cfg.CriticalTopics {
+		result := TopicHealth{Topic: topic, Status: StatusHealthy}
+		if refreshErr != nil {
+			result.Error = fmt.Sprintf("metadata refresh failed: %v", refreshErr)
+		} else if partitions, leaderless, err := h.probe.Partitions(topic); err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Partitions = partitions
+			result.Leaderless = leaderless
+			if len(leaderless) > 0 {
+				result.Error = fmt.Sprintf("%d of %d partitions have no leader", len(leaderless), partitions)
+			}
+		}
+		if result.Error != "" {
+			result.Status = StatusUnhealthy
+			problems = append(problems, fmt.Sprintf("topic %s: %s", topic, result.Error))
+		}
+		health.Topics = append(health.Topics, result)
+	}
+
+	if h.cfg.CanaryEnabled {
+		canary := &CanaryHealth{Topic: h.cfg.CanaryTopic, Status: StatusHealthy}
+		var err error
+		if health.HealthyBrokers == 0 {
+			err = errors.New("skipped, no broker is reachable")
+		} else {
+			start := time.Now()
+			err = h.run(ctx, func(ctx context.Context) error { return h.probe.Canary(ctx, h.cfg.CanaryTopic) })
+			canary.LatencyMS = float64(time.Since(start).Microseconds()) / 1000
+		}
+		if err != nil {
+			canary.Status = StatusUnhealthy
+			canary.Error = err.Error()
+			problems = append(problems, fmt.Sprintf("canary: %v", err))
+		}
+		health.Canary = canary
+	}
+
+	health.Status = StatusHealthy
+	if len(problems) > 0 {
+		health.Status = StatusUnhealthy
+		health.Error = strings.Join(problems, "; ")
+	}
+	return health
+}
+
+// run runs a step of a check, giving up on it after the timeout. A step
+// given up on is left to finish in the background.
+func (h *healthChecker) run(ctx context.Context, step func(context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, h.cfg.Timeout)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- step(ctx) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("timed out after %s", h.cfg.Timeout)
+	}
+}
+
+// saramaProbe implements clusterProbe with the sarama client of the admin
+// and the producer
+type saramaProbe struct {
+	client   sarama.Client
+	producer sarama.SyncProducer
+	// conf is the configuration of the client with the timeouts of the
+	// health check, for the connections of pings
+	conf *sarama.Config
+
+	consumerOnce sync.Once
+	consumer     sarama.Consumer
+	consumerErr  error
+}
+
+func (p *saramaProbe) Refresh(topics []string) error {
+	return p.client.RefreshMetadata(topics...)
+}
+
+func (p *saramaProbe) Brokers() []BrokerHealth {
+	var brokers []BrokerHealth
+	for _, broker := range p.client.Brokers() {
+		brokers = append(brokers, BrokerHealth{ID: broker.ID(), Addr: broker.Addr()})
+	}
+	sort.Slice(brokers, func(i, j int) bool { return brokers[i].ID < brokers[j].ID })
+	return brokers
+}
+
+func (p *saramaProbe) Ping(addr string) error {
+	broker := sarama.NewBroker(addr)
+	if err := broker.Open(p.conf); err != nil {
+		return err
+	}
+	defer broker.Close()
+	_, err := broker.ApiVersions(&sarama.ApiVersionsRequest{})
+	return err
+}
+
+func (p *saramaProbe) Partitions(topic string) (int, []int32, error) {
+	partitions, err := p.client.Partitions(topic)
+	if err != nil {
+		return 0, nil, err
+	}
+	var leaderless []int32
+	for _, partition := range partitions {
+		if _, err := p.client.Leader(topic, partition); err != nil {
+			leaderless = append(leaderless, partition)
+		}
+	}
+	return len(partitions), leaderless, nil
+}
+
+func (p *saramaProbe) Canary(ctx context.Context, topic string) error {
+	p.consumerOnce.Do(func() {
+		p.consumer, p.consumerErr = sarama.NewConsumerFromClient(p.client)
+	})
+	if p.consumerErr != nil {
+		return p.consumerErr
+	}
+
+	sent := time.Now().UTC().Format(time.RFC3339Nano)
+	partition, offset, err := p.producer.SendMessage(&sarama.ProducerMessage{
+		Topic: topic,
+		Key:   sarama.StringEncoder(p.conf.ClientID),
+		Value: sarama.StringEncoder(sent),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to produce: %w", err)
+	}
+
+	consumer, err := p.consumer.ConsumePartition(topic, partition, offset)
+	if err != nil {
+		return fmt.Errorf("failed to consume: %w", err)
+	}
+	defer consumer.Close()
+	select {
+	case message := <-consumer.Messages():
+		// The message at the offset is the one produced
+		if string(message.Value) != sent {
+			return fmt.Errorf("consumed an unexpected message at offset %d", offset)
+		}
+		return nil
+	case err := <-consumer.Errors():
+		return fmt.Errorf("failed to consume: %w", err)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,216 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+)
+
+// fakeProbe is a cluster whose brokers are up unless listed as down
+type fakeProbe struct {
+	mu         sync.Mutex
+	brokers    []BrokerHealth
+	down       map[string]bool
+	leaderless map[string][]int32
+	refreshErr error
+	canaryErr  error
+	hang       bool
+	checks     int
+}
+
+func (p *fakeProbe) Refresh([]string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.checks++
+	return p.refreshErr
+}
+
+func (p *fakeProbe) Brokers() []BrokerHealth {
+	return append([]BrokerHealth(nil), p.brokers...)
+}
+
+func (p *fakeProbe) Ping(addr string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.hang {
+		// Blackholed, as a broker behind a dropped route
+		p.mu.Unlock()
+		time.Sleep(time.Second)
+		p.mu.Lock()
+	}
+	if p.down[addr] {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func (p *fakeProbe) Partitions(topic string) (int, []int32, error) {
+	if topic == "missing" {
+		return 0, nil, sarama.ErrUnknownTopicOrPartition
+	}
+	return 3, p.leaderless[topic], nil
+}
+
+func (p *fakeProbe) Canary(context.Context, string) error {
+	return p.canaryErr
+}
+
+func newFakeProbe() *fakeProbe {
+	return &fakeProbe{
+		brokers: []BrokerHealth{{ID: 1, Addr: "kafka-1:9092"}, {ID: 2, Addr: "kafka-2:9092"}, {ID: 3, Addr: "kafka-3:9092"}},
+		down:    make(map[string]bool),
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.KafkaHealthConfig
+		setup   func(p *fakeProbe)
+		healthy bool
+		errors  []string
+	}{
+		{"all brokers up", config.KafkaHealthConfig{MinHealthyBrokers: 2}, func(*fakeProbe) {}, true, nil},
+		{"enough brokers up", config.KafkaHealthConfig{MinHealthyBrokers: 2}, func(p *fakeProbe) { p.down["kafka-3:9092"] = true }, true, nil},
+		{"too few brokers up", config.KafkaHealthConfig{MinHealthyBrokers: 2}, func(p *fakeProbe) {
+			p.down["kafka-2:9092"] = true
+			p.down["kafka-3:9092"] = true
+		}, false, []string{"1 of 3 brokers reachable, 2 required"}},
+		{"full outage", config.KafkaHealthConfig{CriticalTopics: []string{"form.events"}, CanaryEnabled: true, CanaryTopic: "_healthcheck"}, func(p *fakeProbe) {
+			p.refreshErr = sarama.ErrOutOfBrokers
+			for _, broker := range p.brokers {
+				p.down[broker.Addr] = true
+			}
+		}, false, []string{"0 of 3 brokers reachable", "topic form.events: metadata refresh failed", "canary: skipped"}},
+		{"leaderless partitions", config.KafkaHealthConfig{CriticalTopics: []string{"form.events", "audit-log"}}, func(p *fakeProbe) {
+			p.leaderless = map[string][]int32{"audit-log": {1}}
+		}, false, []string{"topic audit-log: 1 of 3 partitions have no leader"}},
+		{"unknown critical topic", config.KafkaHealthConfig{CriticalTopics: []string{"missing"}}, func(*fakeProbe) {}, false, []string{"topic missing"}},
+		{"canary failed", config.KafkaHealthConfig{CanaryEnabled: true, CanaryTopic: "_healthcheck"}, func(p *fakeProbe) {
+			p.canaryErr = errors.New("failed to produce")
+		}, false, []string{"canary: failed to produce"}},
+		{"unreachable broker times out", config.KafkaHealthConfig{Timeout: 20 * time.Millisecond}, func(p *fakeProbe) { p.hang = true }, false, []string{"0 of 3 brokers reachable"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probe := newFakeProbe()
+			tt.setup(probe)
+			health := newHealthChecker(tt.cfg, nil, probe).Health(context.Background())
+			if healthy := health.Status == StatusHealthy; healthy != tt.healthy {
+				t.Fatalf("status = %s (%s), want healthy %v", health.Status, health.Error, tt.healthy)
+			}
+			for _, want := range tt.errors {
+				if !strings.Contains(health.Error, want) {
+					t.Errorf("error %q does not mention %q", health.Error, want)
+				}
+			}
+		})
+	}
+}
+
+func TestHealthReportsBrokers(t *testing.T) {
+	probe := newFakeProbe()
+	probe.down["kafka-2:9092"] = true
+	health := newHealthChecker(config.KafkaHealthConfig{CanaryEnabled: true, CanaryTopic: "_healthcheck"}, nil, probe).Health(context.Background())
+
+	var statuses []string
+	for _, broker := range health.Brokers {
+		statuses = append(statuses, broker.Status)
+	}
+	if !reflect.DeepEqual(statuses, []string{StatusUp, StatusDown, StatusUp}) || health.HealthyBrokers != 2 {
+		t.Errorf("brokers = %+v, want kafka-2 down", health.Brokers)
+	}
+	if health.Canary == nil || health.Canary.Status != StatusHealthy {
+		t.Errorf("canary = %+v, want healthy", health.Canary)
+	}
+
+	// Without metadata the bootstrap brokers are pinged
+	probe.brokers = nil
+	health = newHealthChecker(config.KafkaHealthConfig{}, []string{"kafka-2:9092"}, probe).Health(context.Background())
+	if len(health.Brokers) != 1 || health.Brokers[0].ID != -1 || health.Status != StatusUnhealthy {
+		t.Errorf("health = %+v, want the bootstrap broker down", health)
+	}
+}
+
+func TestHealthCached(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	probe := newFakeProbe()
+	checker := newHealthChecker(config.KafkaHealthConfig{CacheTTL: 5 * time.Second}, nil, probe)
+	checker.now = func() time.Time { return now }
+
+	if health := checker.Health(context.Background()); health.Status != StatusHealthy {
+		t.Fatalf("status = %s, want healthy", health.Status)
+	}
+	for _, broker := range probe.brokers {
+		probe.down[broker.Addr] = true
+	}
+
+	// The outage shows once the cached result expires
+	now = now.Add(4 * time.Second)
+	if health := checker.Health(context.Background()); health.Status != StatusHealthy || probe.checks != 1 {
+		t.Errorf("within the TTL: status = %s after %d checks, want the cached result", health.Status, probe.checks)
+	}
+	now = now.Add(time.Second)
+	if health := checker.Health(context.Background()); health.Status != StatusUnhealthy || probe.checks != 2 {
+		t.Errorf("after the TTL: status = %s after %d checks, want a new check", health.Status, probe.checks)
+	}
+}
+
+func TestSaramaProbe(t *testing.T) {
+	leader := sarama.NewMockBroker(t, 1)
+	defer leader.Close()
+	follower := sarama.NewMockBroker(t, 2)
+	handlers := map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(leader.Addr(), leader.BrokerID()).
+			SetBroker(follower.Addr(), follower.BrokerID()).
+			SetLeader("form.events", 0, leader.BrokerID()).
+			SetLeader("form.events", 1, follower.BrokerID()).
+			// Broker 3 is not in the cluster
+			SetLeader("form.events", 2, 3),
+		"ApiVersionsRequest": sarama.NewMockApiVersionsResponse(t),
+	}
+	leader.SetHandlerByMap(handlers)
+	follower.SetHandlerByMap(handlers)
+
+	conf := sarama.NewConfig()
+	conf.Net.DialTimeout = time.Second
+	conf.Metadata.Retry.Max = 0
+	client, err := sarama.NewClient([]string{leader.Addr()}, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	probe := &saramaProbe{client: client, conf: conf}
+
+	if err := probe.Refresh([]string{"form.events"}); err != nil {
+		t.Fatal(err)
+	}
+	if brokers := probe.Brokers(); len(brokers) != 2 {
+		t.Errorf("brokers = %+v, want both", brokers)
+	}
+	partitions, leaderless, err := probe.Partitions("form.events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if partitions != 3 || !reflect.DeepEqual(leaderless, []int32{2}) {
+		t.Errorf("partitions = %d, leaderless %v; want 3 and [2]", partitions, leaderless)
+	}
+
+	if err := probe.Ping(follower.Addr()); err != nil {
+		t.Errorf("ping of a live broker: %v", err)
+	}
+	addr := follower.Addr()
+	follower.Close()
+	if err := probe.Ping(addr); err == nil {
+		t.Error("ping of a closed broker succeeded")
+	}
+}
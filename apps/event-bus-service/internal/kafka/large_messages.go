@@ -0,0 +1,85 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/IBM/sarama"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/claimcheck"
+)
+
+// ErrMessageTooLarge is returned for messages over the producer's
+// max_message_bytes that cannot be spilled to a claim check store
+var ErrMessageTooLarge = errors.New("message too large")
+
+// compressionCodec maps the configured producer compression to its codec
+func compressionCodec(name string) sarama.CompressionCodec {
+	switch name {
+	case "none":
+		return sarama.CompressionNone
+	case "gzip":
+		return sarama.CompressionGZIP
+	case "lz4":
+		return sarama.CompressionLZ4
+	case "zstd":
+		return sarama.CompressionZSTD
+	default:
+		return sarama.CompressionSnappy
+	}
+}
+
+// fitMessage returns kafkaMessage when it fits the producer's
+// max_message_bytes. An oversized message is rejected with
+// ErrMessageTooLarge or, in the claim_check large message mode, replaced by
+// a message whose data references the payload in object storage.
+func (c *Client) fitMessage(ctx context.Context, message *Message, kafkaMessage *sarama.ProducerMessage) (*sarama.ProducerMessage, error) {
+	limit := c.config.Kafka.Producer.MaxMessageBytes
+	size := kafkaMessage.ByteSize(c.recordVersion)
+	if size <= limit {
+		return kafkaMessage, nil
+	}
+	if c.config.Kafka.Producer.LargeMessageMode != "claim_check" || c.claimChecks == nil {
+		return nil, fmt.Errorf("%w: %d bytes exceeds the limit of %d bytes for topic %s", ErrMessageTooLarge, size, limit, message.Topic)
+	}
+
+	// The stored payload is the whole serialized message, so consumers
+	// decode it exactly as they would have the original
+	payload, err := kafkaMessage.Value.Encode()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode message: %w", err)
+	}
+	key := path.Join(c.config.Kafka.Producer.ClaimCheck.Prefix, message.Topic, message.ID)
+	ref, err := c.claimChecks.Put(ctx, key, payload, headerValue(kafkaMessage, "content-type"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to store claim check for message %s: %w", message.ID, err)
+	}
+
+	claim := *message
+	claim.Data = ref
+	claim.Headers = make(map[string]string, len(message.Headers)+1)
+	for k, v := range message.Headers {
+		claim.Headers[k] = v
+	}
+	claim.Headers[claimcheck.HeaderName] = "true"
+
+	claimMessage, err := c.prepareKafkaMessage(&claim)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare claim check message: %w", err)
+	}
+	if size := claimMessage.ByteSize(c.recordVersion); size > limit {
+		return nil, fmt.Errorf("%w: the claim check message of %d bytes still exceeds the limit of %d bytes for topic %s", ErrMessageTooLarge, size, limit, message.Topic)
+	}
+	return claimMessage, nil
+}
+
+func headerValue(kafkaMessage *sarama.ProducerMessage, key string) string {
+	for _, header := range kafkaMessage.Headers {
+		if string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,177 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	gometrics "github.com/rcrowley/go-metrics"
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/claimcheck"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+)
+
+func producerTestConfig(compression string, maxMessageBytes int) *config.Config {
+	return &config.Config{Kafka: config.KafkaConfig{
+		Version:     "3.5.0",
+		ClientID:    "event-bus-test",
+		EventFormat: "native",
+		Security:    config.KafkaSecurityConfig{Protocol: "PLAINTEXT"},
+		Producer: config.KafkaProducerConfig{
+			RequiredAcks:    1,
+			Compression:     compression,
+			MaxMessageBytes: maxMessageBytes,
+			RetryMax:        1,
+			Timeout:         10 * time.Second,
+		},
+	}}
+}
+
+func largeTestMessage(size int) *Message {
+	message := testMessage()
+	message.Partition = -1
+	message.Data = map[string]interface{}{"answers": strings.Repeat("the same answer ", size/16)}
+	return message
+}
+
+func TestCompressionRoundTrip(t *testing.T) {
+	for _, compression := range []string{"gzip", "snappy", "lz4", "zstd"} {
+		t.Run(compression, func(t *testing.T) {
+			client := &Client{config: producerTestConfig(compression, 1000000), logger: zap.NewNop(), recordVersion: 2}
+			kafkaConfig := sarama.NewConfig()
+			kafkaConfig.Version = sarama.V3_5_0_0
+			client.configureProducer(kafkaConfig)
+			if kafkaConfig.Producer.Compression != compressionCodec(compression) {
+				t.Fatalf("compression = %v, want %s", kafkaConfig.Producer.Compression, compression)
+			}
+
+			// The mock broker decodes, and so decompresses, every produce request
+			broker := sarama.NewMockBroker(t, 1)
+			defer broker.Close()
+			broker.SetHandlerByMap(map[string]sarama.MockResponse{
+				"MetadataRequest": sarama.NewMockMetadataResponse(t).
+					SetBroker(broker.Addr(), broker.BrokerID()).
+					SetLeader("app.form.published", 0, broker.BrokerID()),
+				"ProduceRequest":     sarama.NewMockProduceResponse(t),
+				"ApiVersionsRequest": sarama.NewMockApiVersionsResponse(t),
+			})
+
+			producer, err := sarama.NewSyncProducer([]string{broker.Addr()}, kafkaConfig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer producer.Close()
+
+			kafkaMessage, err := client.prepareKafkaMessage(largeTestMessage(64 * 1024))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, _, err := producer.SendMessage(kafkaMessage); err != nil {
+				t.Fatalf("failed to send a %s message: %v", compression, err)
+			}
+
+			// The producer reports the ratio multiplied by 100
+			ratio := gometrics.GetOrRegisterHistogram("compression-ratio", kafkaConfig.MetricRegistry, gometrics.NewExpDecaySample(1028, 0.015)).Snapshot().Mean() / 100
+			if ratio <= 1 {
+				t.Errorf("compression ratio = %.2f, expected a repetitive payload to compress", ratio)
+			}
+		})
+	}
+}
+
+func TestFitMessageRejectsOversizedMessages(t *testing.T) {
+	client := &Client{config: producerTestConfig("snappy", 4096), logger: zap.NewNop(), recordVersion: 2}
+
+	small, err := client.prepareKafkaMessage(largeTestMessage(512))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := client.fitMessage(context.Background(), testMessage(), small); err != nil || got != small {
+		t.Errorf("a message within the limit was changed: %v", err)
+	}
+
+	message := largeTestMessage(8192)
+	large, err := client.prepareKafkaMessage(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.fitMessage(context.Background(), message, large)
+	if !errors.Is(err, ErrMessageTooLarge) || !strings.Contains(err.Error(), "exceeds the limit of 4096 bytes for topic app.form.published") {
+		t.Errorf("expected ErrMessageTooLarge, got %v", err)
+	}
+}
+
+type memoryClaimChecks struct {
+	objects map[string][]byte
+}
+
+func (s *memoryClaimChecks) Put(_ context.Context, key string, data []byte, contentType string) (*claimcheck.Reference, error) {
+	s.objects[key] = data
+	return &claimcheck.Reference{Bucket: "claims", Key: key, Size: len(data), ContentType: contentType}, nil
+}
+
+func (s *memoryClaimChecks) Get(_ context.Context, ref *claimcheck.Reference) ([]byte, error) {
+	data, ok := s.objects[ref.Key]
+	if !ok {
+		return nil, claimcheck.ErrNotFound
+	}
+	return data, nil
+}
+
+func TestFitMessageClaimCheck(t *testing.T) {
+	cfg := producerTestConfig("snappy", 4096)
+	cfg.Kafka.Producer.LargeMessageMode = "claim_check"
+	cfg.Kafka.Producer.ClaimCheck.Prefix = "claim-checks"
+	store := &memoryClaimChecks{objects: map[string][]byte{}}
+	client := &Client{config: cfg, logger: zap.NewNop(), recordVersion: 2, claimChecks: store}
+
+	message := largeTestMessage(8192)
+	large, err := client.prepareKafkaMessage(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claim, err := client.fitMessage(context.Background(), message, large)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claim.ByteSize(2) > 4096 {
+		t.Errorf("claim check message is %d bytes", claim.ByteSize(2))
+	}
+	if headerValue(claim, claimcheck.HeaderName) != "true" {
+		t.Error("claim check header missing")
+	}
+	if _, ok := message.Headers[claimcheck.HeaderName]; ok {
+		t.Error("the original message was modified")
+	}
+
+	// The reference resolves to the original serialized message
+	value, _ := claim.Value.Encode()
+	var published Message
+	if err := json.Unmarshal(value, &published); err != nil {
+		t.Fatal(err)
+	}
+	refJSON, _ := json.Marshal(published.Data)
+	var ref claimcheck.Reference
+	if err := json.Unmarshal(refJSON, &ref); err != nil {
+		t.Fatal(err)
+	}
+	if ref.Key != "claim-checks/app.form.published/event_1" {
+		t.Errorf("reference key = %s", ref.Key)
+	}
+	payload, err := store.Get(context.Background(), &ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original, err := DecodeMessage(payload, ref.ContentType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if original.ID != message.ID || original.Data.(map[string]interface{})["answers"] != message.Data.(map[string]interface{})["answers"] {
+		t.Error("the stored payload is not the original message")
+	}
+}
@@ -0,0 +1,235 @@
+package kafka
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+)
+
+// RebalanceListener is told the partitions that move to and from the
+// consumer groups of the client at each rebalance. Partitions a member keeps
+// across a rebalance are in neither list, so with the sticky strategies only
+// the partitions that actually moved are revoked and assigned.
+type RebalanceListener interface {
+	// PartitionsRevoked is called with the partitions the group no longer
+	// consumes, before those it was assigned
+	PartitionsRevoked(groupID string, partitions map[string][]int32)
+	// PartitionsAssigned is called with the partitions the group was
+	// assigned
+	PartitionsAssigned(groupID string, partitions map[string][]int32)
+}
+
+// balanceStrategies returns the assignors of a rebalance strategy of
+// kafka.consumer. The client does not implement the incremental protocol,
+// so cooperative-sticky assigns with the sticky assignor and the client
+// reports the partitions that moved. Range is listed second so members
+// agree on a protocol while a group is rolled from range.
+func balanceStrategies(strategy string) ([]sarama.BalanceStrategy, error) {
+	switch strategy {
+	case "", "range":
+		return []sarama.BalanceStrategy{sarama.NewBalanceStrategyRange()}, nil
+	case "roundrobin":
+		return []sarama.BalanceStrategy{sarama.NewBalanceStrategyRoundRobin(), sarama.NewBalanceStrategyRange()}, nil
+	case "sticky", "cooperative-sticky":
+		return []sarama.BalanceStrategy{sarama.NewBalanceStrategySticky(), sarama.NewBalanceStrategyRange()}, nil
+	default:
+		return nil, fmt.Errorf("unsupported rebalance strategy %q", strategy)
+	}
+}
+
+// assignment is the partitions a consumer group of the client consumes, and
+// those of them paused. Pauses outlive the claims of a session: a paused
+// partition kept across a rebalance is paused again when its claim restarts.
+type assignment struct {
+	mu         sync.Mutex
+	partitions map[string][]int32
+	paused     map[string]map[int32]bool
+}
+
+// update replaces the partitions with the claims of a new session and
+// returns those assigned and revoked since the previous one. Pauses of the
+// revoked partitions are dropped.
+func (a *assignment) update(claims map[string][]int32) (assigned, revoked map[string][]int32) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	assigned = diffPartitions(claims, a.partitions)
+	revoked = diffPartitions(a.partitions, claims)
+	for topic, partitions := range revoked {
+		for _, partition := range partitions {
+			delete(a.paused[topic], partition)
+		}
+	}
+	a.partitions = copyPartitions(claims)
+	return assigned, revoked
+}
+
+// current returns a copy of the partitions
+func (a *assignment) current() map[string][]int32 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return copyPartitions(a.partitions)
+}
+
+// setPaused records partitions as paused or resumed
+func (a *assignment) setPaused(partitions map[string][]int32, paused bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for topic, list := range partitions {
+		for _, partition := range list {
+			if !paused {
+				delete(a.paused[topic], partition)
+				continue
+			}
+			if a.paused == nil {
+				a.paused = make(map[string]map[int32]bool)
+			}
+			if a.paused[topic] == nil {
+				a.paused[topic] = make(map[int32]bool)
+			}
+			a.paused[topic][partition] = true
+		}
+	}
+}
+
+// isPaused tells whether a partition is paused
+func (a *assignment) isPaused(topic string, partition int32) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.paused[topic][partition]
+}
+
+// diffPartitions returns the partitions of a missing from b
+func diffPartitions(a, b map[string][]int32) map[string][]int32 {
+	diff := make(map[string][]int32)
+	for topic, partitions := range a {
+		for _, partition := range partitions {
+			if !slices.Contains(b[topic], partition) {
+				diff[topic] = append(diff[topic], partition)
+			}
+		}
+	}
+	for _, partitions := range diff {
+		slices.Sort(partitions)
+	}
+	return diff
+}
+
+// copyPartitions returns a sorted copy of partitions
+func copyPartitions(partitions map[string][]int32) map[string][]int32 {
+	copied := make(map[string][]int32, len(partitions))
+	for topic, list := range partitions {
+		copied[topic] = slices.Sorted(slices.Values(list))
+	}
+	return copied
+}
+
+// countPartitions returns the number of partitions
+func countPartitions(partitions map[string][]int32) int {
+	n := 0
+	for _, list := range partitions {
+		n += len(list)
+	}
+	return n
+}
+
+// AddRebalanceListener registers a listener of the assignment changes of
+// the consumer groups of the client
+func (c *Client) AddRebalanceListener(listener RebalanceListener) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.rebalanceListeners = append(c.rebalanceListeners, listener)
+}
+
+// rebalanced records the claims of a new session of group, logs the
+// partitions that moved and tells the listeners
+func (c *Client) rebalanced(group *switchableGroup, session sarama.ConsumerGroupSession) {
+	c.reassign(group, session.Claims(), session.GenerationID())
+}
+
+// released revokes every partition of group once it stops consuming
+func (c *Client) released(group *switchableGroup) {
+	c.reassign(group, nil, -1)
+}
+
+func (c *Client) reassign(group *switchableGroup, claims map[string][]int32, generation int32) {
+	if group == nil {
+		return
+	}
+	assigned, revoked := group.assignment.update(claims)
+	if len(assigned) == 0 && len(revoked) == 0 {
+		c.logger.Debug("Consumer group assignment unchanged",
+			zap.String("group_id", group.groupID),
+			zap.Int32("generation", generation),
+			zap.Int("partitions", countPartitions(claims)))
+		return
+	}
+	c.logger.Info("Consumer group assignment changed",
+		zap.String("group_id", group.groupID),
+		zap.Int32("generation", generation),
+		zap.Any("assigned", assigned),
+		zap.Any("revoked", revoked),
+		zap.Any("partitions", claims))
+
+	c.mutex.RLock()
+	listeners := slices.Clone(c.rebalanceListeners)
+	c.mutex.RUnlock()
+	for _, listener := range listeners {
+		if len(revoked) > 0 {
+			listener.PartitionsRevoked(group.groupID, revoked)
+		}
+		if len(assigned) > 0 {
+			listener.PartitionsAssigned(group.groupID, assigned)
+		}
+	}
+}
+
+// claimStarted pauses the partition of a claim again when it was paused
+// before the rebalance that restarted it
+func (c *Client) claimStarted(group *switchableGroup, claim sarama.ConsumerGroupClaim) {
+	if group != nil && group.assignment.isPaused(claim.Topic(), claim.Partition()) {
+		group.current().Pause(map[string][]int32{claim.Topic(): {claim.Partition()}})
+	}
+}
+
+// group returns the consumer group of the client joined as groupID
+func (c *Client) group(groupID string) *switchableGroup {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	for _, group := range append([]*switchableGroup{c.consumer}, c.batchConsumers...) {
+		if group != nil && group.groupID == groupID {
+			return group
+		}
+	}
+	return nil
+}
+
+// Assignment returns the partitions the consumer group groupID of the
+// client consumes, by topic, or nil when the client has not joined it
+func (c *Client) Assignment(groupID string) map[string][]int32 {
+	group := c.group(groupID)
+	if group == nil {
+		return nil
+	}
+	return group.assignment.current()
+}
+
+// PausePartitions stops fetching partitions of the consumer group groupID
+// until they are resumed, across rebalances that keep them in the group
+func (c *Client) PausePartitions(groupID string, partitions map[string][]int32) {
+	if group := c.group(groupID); group != nil {
+		group.assignment.setPaused(partitions, true)
+		group.current().Pause(partitions)
+	}
+}
+
+// ResumePartitions fetches partitions paused by PausePartitions again
+func (c *Client) ResumePartitions(groupID string, partitions map[string][]int32) {
+	if group := c.group(groupID); group != nil {
+		group.assignment.setPaused(partitions, false)
+		group.current().Resume(partitions)
+	}
+}
@@ -0,0 +1,355 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/shared/errreport"
+)
+
+// rebalanceSession is a session of a generation with the given claims
+type rebalanceSession struct {
+	sarama.ConsumerGroupSession
+	generation int32
+	claims     map[string][]int32
+}
+
+func (s *rebalanceSession) Claims() map[string][]int32 { return s.claims }
+func (s *rebalanceSession) GenerationID() int32        { return s.generation }
+
+// pausingGroup is a consumer group recording the partitions paused and
+// resumed
+type pausingGroup struct {
+	sarama.ConsumerGroup
+	paused, resumed []map[string][]int32
+}
+
+func (g *pausingGroup) Pause(partitions map[string][]int32) {
+	g.paused = append(g.paused, partitions)
+}
+
+func (g *pausingGroup) Resume(partitions map[string][]int32) {
+	g.resumed = append(g.resumed, partitions)
+}
+
+// partitionClaim is the claim of a partition
+type partitionClaim struct {
+	sarama.ConsumerGroupClaim
+	topic     string
+	partition int32
+}
+
+func (c *partitionClaim) Topic() string    { return c.topic }
+func (c *partitionClaim) Partition() int32 { return c.partition }
+
+// recordingListener records the rebalance callbacks
+type recordingListener struct {
+	calls []string
+}
+
+func (l *recordingListener) PartitionsRevoked(groupID string, partitions map[string][]int32) {
+	l.calls = append(l.calls, fmt.Sprintf("revoked %s %v", groupID, partitions))
+}
+
+func (l *recordingListener) PartitionsAssigned(groupID string, partitions map[string][]int32) {
+	l.calls = append(l.calls, fmt.Sprintf("assigned %s %v", groupID, partitions))
+}
+
+func TestRebalancedReportsPartitionsThatMoved(t *testing.T) {
+	sessions := &pausingGroup{}
+	group := &switchableGroup{groupID: "event-bus", group: sessions}
+	client := &Client{logger: zap.NewNop(), consumer: group}
+	listener := &recordingListener{}
+	client.AddRebalanceListener(listener)
+
+	rebalance := func(generation int32, claims map[string][]int32) []string {
+		listener.calls = nil
+		client.rebalanced(group, &rebalanceSession{generation: generation, claims: claims})
+		return listener.calls
+	}
+
+	if calls := rebalance(1, map[string][]int32{"app.form.created": {1, 0}}); !reflect.DeepEqual(calls, []string{
+		"assigned event-bus map[app.form.created:[0 1]]",
+	}) {
+		t.Errorf("first generation: %q", calls)
+	}
+	// Partition 1 stays; only 0 and 2 moved
+	if calls := rebalance(2, map[string][]int32{"app.form.created": {1, 2}, "app.response.created": {0}}); !reflect.DeepEqual(calls, []string{
+		"revoked event-bus map[app.form.created:[0]]",
+		"assigned event-bus map[app.form.created:[2] app.response.created:[0]]",
+	}) {
+		t.Errorf("second generation: %q", calls)
+	}
+	if calls := rebalance(3, map[string][]int32{"app.form.created": {2, 1}, "app.response.created": {0}}); len(calls) != 0 {
+		t.Errorf("a rebalance keeping every partition reported %q", calls)
+	}
+	if got := client.Assignment("event-bus"); !reflect.DeepEqual(got, map[string][]int32{"app.form.created": {1, 2}, "app.response.created": {0}}) {
+		t.Errorf("Assignment = %v", got)
+	}
+
+	// Pauses are applied again to the claims of the partitions kept
+	client.PausePartitions("event-bus", map[string][]int32{"app.form.created": {1}})
+	client.claimStarted(group, &partitionClaim{topic: "app.form.created", partition: 1})
+	client.claimStarted(group, &partitionClaim{topic: "app.form.created", partition: 2})
+	if want := []map[string][]int32{{"app.form.created": {1}}, {"app.form.created": {1}}}; !reflect.DeepEqual(sessions.paused, want) {
+		t.Errorf("paused %v, want %v", sessions.paused, want)
+	}
+	// but not once the partition left and came back
+	rebalance(4, map[string][]int32{"app.form.created": {2}})
+	rebalance(5, map[string][]int32{"app.form.created": {1, 2}})
+	client.claimStarted(group, &partitionClaim{topic: "app.form.created", partition: 1})
+	if len(sessions.paused) != 2 {
+		t.Errorf("a partition revoked while paused was paused again when assigned back")
+	}
+
+	listener.calls = nil
+	client.released(group)
+	if want := []string{"revoked event-bus map[app.form.created:[1 2]]"}; !reflect.DeepEqual(listener.calls, want) {
+		t.Errorf("released: %q, want %q", listener.calls, want)
+	}
+	if client.Assignment("other-group") != nil {
+		t.Error("Assignment of a group the client has not joined is not nil")
+	}
+}
+
+func TestConfigureConsumerGroupMembership(t *testing.T) {
+	tests := []struct {
+		version    string
+		instanceID string
+		strategy   string
+		want       []string
+		err        string
+	}{
+		{"3.5.0", "", "", []string{"range"}, ""},
+		{"3.5.0", "event-bus-1", "cooperative-sticky", []string{"sticky", "range"}, ""},
+		{"3.5.0", "", "roundrobin", []string{"roundrobin", "range"}, ""},
+		{"2.1.0", "event-bus-1", "sticky", nil, "requires kafka version 2.3.0"},
+		{"3.5.0", "", "cooperative", nil, `unsupported rebalance strategy "cooperative"`},
+	}
+	for _, tt := range tests {
+		cfg := producerTestConfig("none", 1000000)
+		cfg.Kafka.Version = tt.version
+		cfg.Kafka.Consumer = config.KafkaConsumerConfig{InstanceID: tt.instanceID, RebalanceStrategy: tt.strategy}
+		client := &Client{config: cfg, logger: zap.NewNop()}
+
+		kafkaConfig, err := client.createKafkaConfig()
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s %q %q: err = %v, want %s", tt.version, tt.instanceID, tt.strategy, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, strategy := range kafkaConfig.Consumer.Group.Rebalance.GroupStrategies {
+			names = append(names, strategy.Name())
+		}
+		if !reflect.DeepEqual(names, tt.want) || kafkaConfig.Consumer.Group.InstanceId != tt.instanceID {
+			t.Errorf("%q %q: strategies %v and instance %q, want %v and %q",
+				tt.instanceID, tt.strategy, names, kafkaConfig.Consumer.Group.InstanceId, tt.want, tt.instanceID)
+		}
+	}
+}
+
+// tickRecorder records when each message is handled, and how often
+type tickRecorder struct {
+	groupID string
+
+	mu       sync.Mutex
+	handled  map[string]int
+	maxDelay time.Duration
+}
+
+func (r *tickRecorder) Handle(_ context.Context, message *Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handled[message.ID]++
+	r.maxDelay = max(r.maxDelay, time.Since(message.Metadata.Timestamp))
+	return nil
+}
+
+func (r *tickRecorder) GetTopics() []string { return nil }
+func (r *tickRecorder) GetGroupID() string  { return r.groupID }
+
+func (r *tickRecorder) stats() (handled int, duplicates []string, maxDelay time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, count := range r.handled {
+		if count > 1 {
+			duplicates = append(duplicates, id)
+		}
+	}
+	return len(r.handled), duplicates, r.maxDelay
+}
+
+// topicHandler consumes topic with the recorder
+type topicHandler struct {
+	*tickRecorder
+	topic string
+}
+
+func (h topicHandler) GetTopics() []string { return []string{h.topic} }
+
+// TestRollingRestartWithStaticMembership restarts the two members of a
+// consumer group one after the other while messages are produced, as a
+// rolling deploy does. Static members get their partitions back when they
+// rejoin, so no message is handled twice and none waits more than a few
+// seconds.
+func TestRollingRestartWithStaticMembership(t *testing.T) {
+	brokers := os.Getenv("EVENTBUS_TEST_KAFKA_BROKERS")
+	if brokers == "" {
+		t.Skip("EVENTBUS_TEST_KAFKA_BROKERS not set")
+	}
+	topic := fmt.Sprintf("rolling-restart-%d", time.Now().UnixNano())
+	configFor := func(instanceID string) *config.Config {
+		cfg := producerTestConfig("none", 1000000)
+		cfg.Kafka.Brokers = strings.Split(brokers, ",")
+		cfg.Kafka.Admin.Timeout = 10 * time.Second
+		cfg.Kafka.Consumer = config.KafkaConsumerConfig{
+			GroupID:            topic,
+			AutoOffsetReset:    "earliest",
+			EnableAutoCommit:   true,
+			AutoCommitInterval: 100 * time.Millisecond,
+			SessionTimeout:     10 * time.Second,
+			HeartbeatInterval:  time.Second,
+			MaxProcessingTime:  time.Second,
+			FetchMin:           1,
+			FetchDefault:       1024 * 1024,
+			MaxWaitTime:        100 * time.Millisecond,
+			ChannelBufferSize:  256,
+			ReturnErrors:       true,
+			InstanceID:         instanceID,
+			RebalanceStrategy:  "cooperative-sticky",
+		}
+		return cfg
+	}
+
+	probe := &Client{config: configFor(""), logger: zap.NewNop()}
+	kafkaConfig, err := probe.createKafkaConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin, err := sarama.NewClusterAdmin(probe.config.Kafka.Brokers, kafkaConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+	if err := admin.CreateTopic(topic, &sarama.TopicDetail{NumPartitions: 4, ReplicationFactor: 1}, false); err != nil {
+		t.Fatal(err)
+	}
+	defer admin.DeleteTopic(topic)
+	defer admin.DeleteConsumerGroup(topic)
+
+	recorder := &tickRecorder{groupID: topic, handled: make(map[string]int)}
+	type member struct {
+		client *Client
+		cancel context.CancelFunc
+	}
+	start := func(instanceID string) *member {
+		t.Helper()
+		metrics := testMetrics()
+		metrics.MessagesConsumed = prometheus.NewCounter(prometheus.CounterOpts{Name: "consumed"})
+		metrics.ConsumerErrors = prometheus.NewCounter(prometheus.CounterOpts{Name: "consumer_errors"})
+		metrics.ConsumerLatency = prometheus.NewHistogram(prometheus.HistogramOpts{Name: "consumer_latency"})
+		client := &Client{config: configFor(instanceID), logger: zap.NewNop(), metrics: metrics, reporter: errreport.NewNoop()}
+		memberConfig, err := client.createKafkaConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := client.initConsumer(memberConfig); err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		if err := client.StartConsumer(ctx, topicHandler{tickRecorder: recorder, topic: topic}); err != nil {
+			t.Fatal(err)
+		}
+		return &member{client: client, cancel: cancel}
+	}
+	// A stopped static member does not leave the group
+	stop := func(m *member) {
+		m.cancel()
+		m.client.consumer.Close()
+	}
+	assigned := func(m *member) int { return countPartitions(m.client.Assignment(topic)) }
+	waitFor := func(what string, done func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(time.Minute)
+		for !done() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+
+	a, b := start("event-bus-0"), start("event-bus-1")
+	waitFor("both members to be assigned partitions", func() bool {
+		return assigned(a) > 0 && assigned(b) > 0 && assigned(a)+assigned(b) == 4
+	})
+	before := map[string]map[string][]int32{"event-bus-0": a.client.Assignment(topic), "event-bus-1": b.client.Assignment(topic)}
+
+	producer, err := sarama.NewSyncProducer(probe.config.Kafka.Brokers, kafkaConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer producer.Close()
+	produced := 0
+	produce := func(d time.Duration) {
+		t.Helper()
+		for end := time.Now().Add(d); time.Now().Before(end); time.Sleep(10 * time.Millisecond) {
+			value := fmt.Sprintf(`{"id":"tick-%d","event_type":"test.tick"}`, produced)
+			if _, _, err := producer.SendMessage(&sarama.ProducerMessage{
+				Topic:     topic,
+				Key:       sarama.StringEncoder(strconv.Itoa(produced)),
+				Value:     sarama.StringEncoder(value),
+				Timestamp: time.Now(),
+			}); err != nil {
+				t.Fatal(err)
+			}
+			produced++
+		}
+	}
+
+	// Roll the members while producing, a second apart, as pods restart
+	produce(2 * time.Second)
+	stop(a)
+	produce(time.Second)
+	a = start("event-bus-0")
+	produce(3 * time.Second)
+	stop(b)
+	produce(time.Second)
+	b = start("event-bus-1")
+	produce(3 * time.Second)
+	defer stop(a)
+	defer stop(b)
+
+	waitFor("every message to be handled", func() bool {
+		handled, _, _ := recorder.stats()
+		return handled == produced
+	})
+	handled, duplicates, maxDelay := recorder.stats()
+	if len(duplicates) > 0 {
+		t.Errorf("%d of %d messages were handled more than once: %v", len(duplicates), handled, duplicates)
+	}
+	if maxDelay > 5*time.Second {
+		t.Errorf("a message waited %s to be handled, want the restarts to pause processing less than 5s", maxDelay)
+	}
+	after := map[string]map[string][]int32{"event-bus-0": a.client.Assignment(topic), "event-bus-1": b.client.Assignment(topic)}
+	if !reflect.DeepEqual(after, before) {
+		t.Errorf("assignment after the restarts = %v, want the members to keep %v", after, before)
+	}
+}
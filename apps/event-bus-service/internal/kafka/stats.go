@@ -0,0 +1,574 @@
+package kafka
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+)
+
+// Directions of the messages sampled by the topic stats
+const (
+	directionPublished = iota
+	directionConsumed
+)
+
+var directionNames = [2]string{"published", "consumed"}
+
+const (
+	// otherTopic labels the topics outside the busiest in the metrics, and
+	// counts the messages of the topics past kafka.stats.max_topics
+	otherTopic = "other"
+	// statsTicks is the number of counts kept over the rate window
+	statsTicks = 12
+	// maxEventTypes bounds the event types counted per topic and direction
+	maxEventTypes = 20
+)
+
+// TopicStatsMetrics are the metrics of the topic stats. Their topic label
+// is bounded by kafka.stats.top_topics.
+type TopicStatsMetrics struct {
+	MessageSize *prometheus.HistogramVec
+	LogBytes    *prometheus.GaugeVec
+}
+
+// NewTopicStatsMetrics creates the topic stats metrics, registered with reg
+func NewTopicStatsMetrics(reg prometheus.Registerer) *TopicStatsMetrics {
+	m := &TopicStatsMetrics{
+		MessageSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "kafka_topic_message_size_bytes",
+			Help:    "Histogram of the size of the messages published and consumed, by topic and direction; topics outside the busiest are labelled other",
+			Buckets: prometheus.ExponentialBuckets(256, 4, 8),
+		}, []string{"topic", "direction"}),
+		LogBytes: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "kafka_topic_log_bytes",
+			Help: "Size of a replica of the partitions of the topics; topics outside the busiest are summed as other",
+		}, []string{"topic"}),
+	}
+	if reg != nil {
+		reg.MustRegister(m.MessageSize, m.LogBytes)
+	}
+	return m
+}
+
+// TopicStatsReport is the volume of the topics reported by
+// /admin/topic-stats
+type TopicStatsReport struct {
+	// RateWindow is the window rates are measured over
+	RateWindow string `json:"rate_window" example:"1m0s"`
+	// LogSizesAt is when the log sizes were read, null until they are
+	LogSizesAt *time.Time `json:"log_sizes_at"`
+	// Topics are sorted by rate, busiest first
+	Topics []TopicStats `json:"topics"`
+}
+
+// TopicStats is the volume of a topic
+type TopicStats struct {
+	Topic     string       `json:"topic" example:"app.form.response.created"`
+	Published MessageStats `json:"published"`
+	Consumed  MessageStats `json:"consumed"`
+	// LogBytes is the size of a replica of the partitions of the topic,
+	// null until log sizes are read
+	LogBytes   *int64 `json:"log_bytes"`
+	Partitions int    `json:"partitions,omitempty"`
+	// PartitionSkew is the ratio of the largest partition to the smallest,
+	// null when a partition is empty
+	PartitionSkew *float64 `json:"partition_skew"`
+}
+
+// MessageStats are the messages of a topic published or consumed by the
+// service
+type MessageStats struct {
+	// Messages counts the messages since the service started
+	Messages int64 `json:"messages"`
+	// Rate is in messages per second over the rate window
+	Rate float64 `json:"rate"`
+	// Size is null without messages
+	Size *SizePercentiles `json:"size"`
+	// EventTypes counts the messages by event type, the types past the
+	// first 20 under other
+	EventTypes map[string]int64 `json:"event_types,omitempty"`
+}
+
+// SizePercentiles are the percentiles of the size of the messages sampled,
+// in bytes. Published messages are sized as the producer encodes them,
+// consumed ones by their key, value and headers.
+type SizePercentiles struct {
+	P50     int `json:"p50"`
+	P95     int `json:"p95"`
+	P99     int `json:"p99"`
+	Samples int `json:"samples"`
+}
+
+// logDirsAdmin reads the log sizes of the partitions from the brokers
+type logDirsAdmin interface {
+	DescribeCluster() ([]*sarama.Broker, int32, error)
+	DescribeLogDirs(brokers []int32) (map[int32][]sarama.DescribeLogDirsResponseDirMetadata, error)
+}
+
+// topicStats samples the messages published and consumed by topic. The
+// sampling of a message takes a map lookup and the lock of its topic and
+// direction; rates, rankings and log sizes are computed off that path.
+type topicStats struct {
+	cfg     config.KafkaStatsConfig
+	metrics *TopicStatsMetrics
+	now     func() time.Time
+
+	topics sync.Map // topic name to *topicSample
+	other  *topicSample
+
+	// mu guards the creation of samples, their labels and counts, and the
+	// log sizes
+	mu       sync.Mutex
+	tracked  int
+	labelled int
+	logs     map[string]topicLog
+	logsAt   time.Time
+}
+
+// topicLog is the log of a topic read from the brokers
+type topicLog struct {
+	bytes      int64
+	partitions int
+	skew       *float64
+}
+
+// topicSample holds the samples of a topic
+type topicSample struct {
+	name       string
+	directions [2]directionSample
+	// observers are the histograms of the label of the topic
+	observers atomic.Pointer[[2]prometheus.Observer]
+
+	// Guarded by topicStats.mu
+	label  string
+	counts [statsTicks + 1]countTick
+	next   int
+}
+
+// countTick is the number of messages of a topic at a tick
+type countTick struct {
+	at       time.Time
+	messages [2]int64
+}
+
+// directionSample holds the messages of a topic in a direction: their
+// count, a reservoir of their sizes and their event types
+type directionSample struct {
+	mu         sync.Mutex
+	messages   int64
+	sizes      []int32
+	rand       uint64
+	eventTypes map[string]int64
+}
+
+func newTopicStats(cfg config.KafkaStatsConfig, metrics *TopicStatsMetrics) *topicStats {
+	s := &topicStats{cfg: cfg, metrics: metrics, now: time.Now}
+	s.other = s.newSample(otherTopic, otherTopic)
+	return s
+}
+
+func (s *topicStats) newSample(name, label string) *topicSample {
+	sample := &topicSample{name: name}
+	seed := uint64(s.now().UnixNano())
+	for i := range sample.directions {
+		sample.directions[i] = directionSample{
+			sizes:      make([]int32, 0, s.cfg.ReservoirSize),
+			rand:       (seed + uint64(i)) | 1,
+			eventTypes: make(map[string]int64),
+		}
+	}
+	sample.counts[0].at = s.now()
+	sample.next = 1
+	s.setLabel(sample, label)
+	return sample
+}
+
+// setLabel moves the metrics of a sample to label, dropping the series of
+// its own label
+func (s *topicStats) setLabel(sample *topicSample, label string) {
+	if sample.label == sample.name && label != sample.name {
+		for _, direction := range directionNames {
+			s.metrics.MessageSize.DeleteLabelValues(sample.name, direction)
+		}
+		s.metrics.LogBytes.DeleteLabelValues(sample.name)
+	}
+	sample.label = label
+	sample.observers.Store(&[2]prometheus.Observer{
+		s.metrics.MessageSize.WithLabelValues(label, directionNames[directionPublished]),
+		s.metrics.MessageSize.WithLabelValues(label, directionNames[directionConsumed]),
+	})
+}
+
+// observe samples a message of size bytes
+func (s *topicStats) observe(direction int, topic, eventType string, size int) {
+	if s == nil {
+		return
+	}
+	sample := s.sample(topic)
+	sample.directions[direction].add(size, eventType)
+	sample.observers.Load()[direction].Observe(float64(size))
+}
+
+// sample returns the sample of a topic, created on its first message
+func (s *topicStats) sample(topic string) *topicSample {
+	if sample, ok := s.topics.Load(topic); ok {
+		return sample.(*topicSample)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sample, ok := s.topics.Load(topic); ok {
+		return sample.(*topicSample)
+	}
+	if s.tracked >= s.cfg.MaxTopics {
+		return s.other
+	}
+	// Topics get a label of their own until the busiest are ranked
+	label := otherTopic
+	if s.labelled < s.cfg.TopTopics {
+		label = topic
+		s.labelled++
+	}
+	sample := s.newSample(topic, label)
+	s.topics.Store(topic, sample)
+	s.tracked++
+	return sample
+}
+
+// add counts a message and samples its size into the reservoir
+func (d *directionSample) add(size int, eventType string) {
+	d.mu.Lock()
+	d.messages++
+	if len(d.sizes) < cap(d.sizes) {
+		d.sizes = append(d.sizes, int32(size))
+	} else if i := d.random() % uint64(d.messages); i < uint64(len(d.sizes)) {
+		d.sizes[i] = int32(size)
+	}
+	if eventType != "" {
+		if _, ok := d.eventTypes[eventType]; !ok && len(d.eventTypes) >= maxEventTypes {
+			eventType = otherTopic
+		}
+		d.eventTypes[eventType]++
+	}
+	d.mu.Unlock()
+}
+
+// random steps the xorshift generator of the reservoir
+func (d *directionSample) random() uint64 {
+	d.rand ^= d.rand << 13
+	d.rand ^= d.rand >> 7
+	d.rand ^= d.rand << 17
+	return d.rand
+}
+
+// snapshot copies the count, sizes and event types of the direction
+func (d *directionSample) snapshot() (int64, []int32, map[string]int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	sizes := append([]int32(nil), d.sizes...)
+	var eventTypes map[string]int64
+	if len(d.eventTypes) > 0 {
+		eventTypes = make(map[string]int64, len(d.eventTypes))
+		for eventType, n := range d.eventTypes {
+			eventTypes[eventType] = n
+		}
+	}
+	return d.messages, sizes, eventTypes
+}
+
+func (d *directionSample) count() int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.messages
+}
+
+// run ticks the rates and reads the log sizes until ctx is done
+func (s *topicStats) run(ctx context.Context, admin logDirsAdmin, logger *zap.Logger) {
+	ticker := time.NewTicker(s.cfg.RateWindow / statsTicks)
+	defer ticker.Stop()
+
+	var readLogs <-chan time.Time
+	if s.cfg.LogSizeInterval > 0 && admin != nil {
+		logTicker := time.NewTicker(s.cfg.LogSizeInterval)
+		defer logTicker.Stop()
+		readLogs = logTicker.C
+		if err := s.readLogSizes(admin); err != nil {
+			logger.Warn("Failed to read the log sizes of the topics", zap.Error(err))
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.tick()
+		case <-readLogs:
+			if err := s.readLogSizes(admin); err != nil {
+				logger.Warn("Failed to read the log sizes of the topics", zap.Error(err))
+			}
+		}
+	}
+}
+
+// samples lists the samples of the topics, other last
+func (s *topicStats) samples() []*topicSample {
+	var samples []*topicSample
+	s.topics.Range(func(_, sample interface{}) bool {
+		samples = append(samples, sample.(*topicSample))
+		return true
+	})
+	return append(samples, s.other)
+}
+
+// tick records the counts of the topics and ranks them by rate: the
+// busiest keep a label of their own in the metrics
+func (s *topicStats) tick() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	samples := s.samples()
+	rates := make(map[*topicSample]float64, len(samples))
+	for _, sample := range samples {
+		tick := countTick{at: now}
+		for i := range sample.directions {
+			tick.messages[i] = sample.directions[i].count()
+		}
+		sample.counts[sample.next%len(sample.counts)] = tick
+		sample.next++
+		published, consumed := sample.rates(tick)
+		rates[sample] = published + consumed
+	}
+
+	ranked := samples[:len(samples)-1]
+	// Ties keep their labels, so idle topics don't trade series
+	sort.Slice(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if rates[a] != rates[b] {
+			return rates[a] > rates[b]
+		}
+		if (a.label == a.name) != (b.label == b.name) {
+			return a.label == a.name
+		}
+		return a.name < b.name
+	})
+	s.labelled = 0
+	for i, sample := range ranked {
+		label := otherTopic
+		if i < s.cfg.TopTopics {
+			label = sample.name
+			s.labelled++
+		}
+		if label != sample.label {
+			s.setLabel(sample, label)
+		}
+	}
+	s.setLogBytes()
+}
+
+// rates are the rates of the messages of the sample up to tick, over the
+// counts kept
+func (sample *topicSample) rates(tick countTick) (float64, float64) {
+	oldest := sample.counts[0]
+	if sample.next > len(sample.counts) {
+		oldest = sample.counts[sample.next%len(sample.counts)]
+	}
+	elapsed := tick.at.Sub(oldest.at).Seconds()
+	if elapsed <= 0 {
+		return 0, 0
+	}
+	return float64(tick.messages[directionPublished]-oldest.messages[directionPublished]) / elapsed,
+		float64(tick.messages[directionConsumed]-oldest.messages[directionConsumed]) / elapsed
+}
+
+// readLogSizes reads the size of the partitions of every topic from the
+// brokers. Replicas are expected to hold the same log, so a partition is
+// sized by its largest replica.
+func (s *topicStats) readLogSizes(admin logDirsAdmin) error {
+	brokers, _, err := admin.DescribeCluster()
+	if err != nil {
+		return err
+	}
+	ids := make([]int32, len(brokers))
+	for i, broker := range brokers {
+		ids[i] = broker.ID()
+	}
+	dirs, err := admin.DescribeLogDirs(ids)
+	if err != nil {
+		return err
+	}
+
+	partitions := make(map[string]map[int32]int64)
+	for _, brokerDirs := range dirs {
+		for _, dir := range brokerDirs {
+			if dir.ErrorCode != sarama.ErrNoError {
+				continue
+			}
+			for _, topic := range dir.Topics {
+				sizes := partitions[topic.Topic]
+				if sizes == nil {
+					sizes = make(map[int32]int64)
+					partitions[topic.Topic] = sizes
+				}
+				for _, partition := range topic.Partitions {
+					if !partition.IsTemporary && partition.Size >= sizes[partition.PartitionID] {
+						sizes[partition.PartitionID] = partition.Size
+					}
+				}
+			}
+		}
+	}
+
+	logs := make(map[string]topicLog, len(partitions))
+	for topic, sizes := range partitions {
+		log := topicLog{partitions: len(sizes)}
+		var largest, smallest int64 = 0, math.MaxInt64
+		for _, size := range sizes {
+			log.bytes += size
+			largest = max(largest, size)
+			smallest = min(smallest, size)
+		}
+		if smallest > 0 {
+			skew := float64(largest) / float64(smallest)
+			log.skew = &skew
+		}
+		logs[topic] = log
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logs, s.logsAt = logs, s.now()
+	s.setLogBytes()
+	return nil
+}
+
+// setLogBytes sets the log sizes of the topics labelled by name, summing
+// the others under other
+func (s *topicStats) setLogBytes() {
+	if s.logs == nil {
+		return
+	}
+	var other int64
+	for topic, log := range s.logs {
+		if sample, ok := s.topics.Load(topic); ok && sample.(*topicSample).label == topic {
+			s.metrics.LogBytes.WithLabelValues(topic).Set(float64(log.bytes))
+			continue
+		}
+		other += log.bytes
+	}
+	s.metrics.LogBytes.WithLabelValues(otherTopic).Set(float64(other))
+}
+
+// report reports the volume of the topics messages were seen or logs were
+// read for
+func (s *topicStats) report() *TopicStatsReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report := &TopicStatsReport{RateWindow: s.cfg.RateWindow.String(), Topics: []TopicStats{}}
+	if s.logs != nil {
+		at := s.logsAt
+		report.LogSizesAt = &at
+	}
+
+	now := s.now()
+	rates := make(map[string]float64)
+	seen := make(map[string]bool)
+	for _, sample := range s.samples() {
+		stats := TopicStats{Topic: sample.name}
+		tick := countTick{at: now}
+		var directions [2]*MessageStats
+		directions[directionPublished], directions[directionConsumed] = &stats.Published, &stats.Consumed
+		for i, direction := range directions {
+			messages, sizes, eventTypes := sample.directions[i].snapshot()
+			tick.messages[i] = messages
+			direction.Messages, direction.EventTypes = messages, eventTypes
+			direction.Size = percentiles(sizes)
+		}
+		if sample == s.other && tick.messages == [2]int64{} {
+			continue
+		}
+		stats.Published.Rate, stats.Consumed.Rate = sample.rates(tick)
+		s.addLog(&stats)
+		seen[sample.name] = true
+		rates[sample.name] = stats.Published.Rate + stats.Consumed.Rate
+		report.Topics = append(report.Topics, stats)
+	}
+	for topic := range s.logs {
+		if !seen[topic] {
+			stats := TopicStats{Topic: topic}
+			s.addLog(&stats)
+			report.Topics = append(report.Topics, stats)
+		}
+	}
+
+	sort.Slice(report.Topics, func(i, j int) bool {
+		a, b := report.Topics[i], report.Topics[j]
+		if rates[a.Topic] != rates[b.Topic] {
+			return rates[a.Topic] > rates[b.Topic]
+		}
+		return a.Topic < b.Topic
+	})
+	return report
+}
+
+// addLog completes the stats of a topic with its log
+func (s *topicStats) addLog(stats *TopicStats) {
+	log, ok := s.logs[stats.Topic]
+	if !ok {
+		return
+	}
+	bytes := log.bytes
+	stats.LogBytes, stats.Partitions, stats.PartitionSkew = &bytes, log.partitions, log.skew
+}
+
+// percentiles computes the nearest-rank percentiles of sizes, nil when
+// there are none
+func percentiles(sizes []int32) *SizePercentiles {
+	if len(sizes) == 0 {
+		return nil
+	}
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i] < sizes[j] })
+	rank := func(p float64) int {
+		return int(sizes[int(math.Ceil(p*float64(len(sizes))))-1])
+	}
+	return &SizePercentiles{P50: rank(0.50), P95: rank(0.95), P99: rank(0.99), Samples: len(sizes)}
+}
+
+// consumedSize is the size of a consumed message: its key, value and
+// headers
+func consumedSize(message *sarama.ConsumerMessage) int {
+	size := len(message.Key) + len(message.Value)
+	for _, header := range message.Headers {
+		if header != nil {
+			size += len(header.Key) + len(header.Value)
+		}
+	}
+	return size
+}
+
+// initStats starts sampling the messages into the topic stats
+func (c *Client) initStats() {
+	c.stats = newTopicStats(c.config.Kafka.Stats, NewTopicStatsMetrics(prometheus.DefaultRegisterer))
+	ctx, cancel := context.WithCancel(context.Background())
+	c.stopStats = cancel
+	go c.stats.run(ctx, c.admin, c.logger)
+}
+
+// TopicStats reports the volume of the topics, nil without kafka.stats
+func (c *Client) TopicStats() *TopicStatsReport {
+	if c.stats == nil {
+		return nil
+	}
+	return c.stats.report()
+}
@@ -0,0 +1,236 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+)
+
+// fakeLogDirs answers the log dirs of two brokers, each with a replica of
+// every partition
+type fakeLogDirs struct {
+	dirs map[int32][]sarama.DescribeLogDirsResponseDirMetadata
+	err  error
+}
+
+func (a *fakeLogDirs) DescribeCluster() ([]*sarama.Broker, int32, error) {
+	return []*sarama.Broker{sarama.NewBroker("kafka-1:9092"), sarama.NewBroker("kafka-2:9092")}, 1, nil
+}
+
+func (a *fakeLogDirs) DescribeLogDirs([]int32) (map[int32][]sarama.DescribeLogDirsResponseDirMetadata, error) {
+	return a.dirs, a.err
+}
+
+func logDir(topic string, sizes ...int64) sarama.DescribeLogDirsResponseDirMetadata {
+	partitions := make([]sarama.DescribeLogDirsResponsePartition, len(sizes))
+	for i, size := range sizes {
+		partitions[i] = sarama.DescribeLogDirsResponsePartition{PartitionID: int32(i), Size: size}
+	}
+	return sarama.DescribeLogDirsResponseDirMetadata{
+		Path:   "/var/lib/kafka",
+		Topics: []sarama.DescribeLogDirsResponseTopic{{Topic: topic, Partitions: partitions}},
+	}
+}
+
+func testStats(cfg config.KafkaStatsConfig) (*topicStats, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+	stats := newTopicStats(cfg, NewTopicStatsMetrics(nil))
+	stats.now = clock.now
+	stats.other = stats.newSample(otherTopic, otherTopic)
+	return stats, clock
+}
+
+// fakeClock is a clock advanced by the tests
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+func findTopic(report *TopicStatsReport, topic string) *TopicStats {
+	for i := range report.Topics {
+		if report.Topics[i].Topic == topic {
+			return &report.Topics[i]
+		}
+	}
+	return nil
+}
+
+func TestTopicStats(t *testing.T) {
+	stats, clock := testStats(config.KafkaStatsConfig{ReservoirSize: 1000, RateWindow: time.Minute, TopTopics: 1, MaxTopics: 2})
+
+	// Sizes 1 to 1000 bytes on the busiest topic, 10 messages on another
+	for size := 1; size <= 1000; size++ {
+		stats.observe(directionPublished, "app.form.response.created", "form.response.created", size)
+	}
+	for i := 0; i < 10; i++ {
+		stats.observe(directionConsumed, "app.form.updated", fmt.Sprintf("type-%d", i%2), 4096)
+	}
+	// Past max_topics, messages are counted under other
+	stats.observe(directionPublished, "app.audit", "audit.recorded", 100)
+
+	clock.advance(10 * time.Second)
+	stats.tick()
+	admin := &fakeLogDirs{dirs: map[int32][]sarama.DescribeLogDirsResponseDirMetadata{
+		// A lagging replica is smaller than the leader
+		1: {logDir("app.form.response.created", 4000, 1000, 2000), logDir("app.form.updated", 0, 512)},
+		2: {logDir("app.form.response.created", 3000, 1000, 2000)},
+	}}
+	if err := stats.readLogSizes(admin); err != nil {
+		t.Fatal(err)
+	}
+	report := stats.report()
+
+	if report.RateWindow != "1m0s" || report.LogSizesAt == nil || !report.LogSizesAt.Equal(clock.now()) {
+		t.Errorf("report window %s, log sizes at %v", report.RateWindow, report.LogSizesAt)
+	}
+	if len(report.Topics) != 3 || report.Topics[0].Topic != "app.form.response.created" {
+		t.Fatalf("topics %+v, want the busiest first and other", report.Topics)
+	}
+
+	busiest := report.Topics[0]
+	if busiest.Published.Messages != 1000 || busiest.Published.Rate != 100 {
+		t.Errorf("published %d at %v/s, want 1000 at 100/s", busiest.Published.Messages, busiest.Published.Rate)
+	}
+	if size := busiest.Published.Size; size == nil || *size != (SizePercentiles{P50: 500, P95: 950, P99: 990, Samples: 1000}) {
+		t.Errorf("sizes %+v", size)
+	}
+	if busiest.Consumed.Size != nil || busiest.Published.EventTypes["form.response.created"] != 1000 {
+		t.Errorf("consumed %+v, event types %v", busiest.Consumed, busiest.Published.EventTypes)
+	}
+	if busiest.LogBytes == nil || *busiest.LogBytes != 7000 || busiest.Partitions != 3 || busiest.PartitionSkew == nil || *busiest.PartitionSkew != 4 {
+		t.Errorf("log of %d bytes in %d partitions, skew %v; want 7000 in 3, skew 4", busiest.LogBytes, busiest.Partitions, busiest.PartitionSkew)
+	}
+
+	updated := findTopic(report, "app.form.updated")
+	if updated == nil || updated.Consumed.Messages != 10 || updated.Consumed.EventTypes["type-1"] != 5 || updated.PartitionSkew != nil {
+		t.Errorf("app.form.updated: %+v; want 10 consumed and no skew with an empty partition", updated)
+	}
+	if other := findTopic(report, otherTopic); other == nil || other.Published.Messages != 1 || findTopic(report, "app.audit") != nil {
+		t.Errorf("other: %+v; want the message of the topic past max_topics", other)
+	}
+
+	// Only the busiest topic is labelled by name
+	if n := testutil.CollectAndCount(stats.metrics.MessageSize); n != 4 {
+		t.Errorf("%d message size series, want the two directions of the busiest topic and other", n)
+	}
+	if got := testutil.ToFloat64(stats.metrics.LogBytes.WithLabelValues(otherTopic)); got != 512 {
+		t.Errorf("log bytes of other = %v, want 512", got)
+	}
+
+	// Rates cover the window only
+	for i := 0; i < statsTicks; i++ {
+		clock.advance(5 * time.Second)
+		stats.tick()
+	}
+	if rate := findTopic(stats.report(), "app.form.response.created").Published.Rate; rate != 0 {
+		t.Errorf("rate %v/s a window after the last message, want 0", rate)
+	}
+
+	// A topic overtaking the busiest takes its label
+	for i := 0; i < 100; i++ {
+		stats.observe(directionConsumed, "app.form.updated", "form.updated", 2048)
+	}
+	clock.advance(5 * time.Second)
+	stats.tick()
+	if got := testutil.ToFloat64(stats.metrics.LogBytes.WithLabelValues("app.form.updated")); got != 512 {
+		t.Errorf("log bytes of app.form.updated = %v, want 512 once labelled", got)
+	}
+	if n := testutil.CollectAndCount(stats.metrics.MessageSize); n != 4 {
+		t.Errorf("%d message size series after the ranking changed, want 4", n)
+	}
+
+	admin.err = errors.New("broker unreachable")
+	if err := stats.readLogSizes(admin); err == nil {
+		t.Error("read log sizes despite the failure")
+	}
+}
+
+func TestTopicStatsConcurrentObserve(t *testing.T) {
+	stats, clock := testStats(config.KafkaStatsConfig{ReservoirSize: 64, RateWindow: time.Minute, TopTopics: 2, MaxTopics: 100})
+
+	const publishers, messages = 8, 2000
+	var wg sync.WaitGroup
+	for p := 0; p < publishers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < messages; i++ {
+				topic := fmt.Sprintf("topic-%d", i%4)
+				stats.observe(p%2, topic, "event", 100+i)
+			}
+		}(p)
+	}
+	// Ranks and reports while messages are observed
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			clock.advance(time.Second)
+			stats.tick()
+			stats.report()
+		}
+	}()
+	wg.Wait()
+	<-done
+
+	var total int64
+	for _, topic := range stats.report().Topics {
+		total += topic.Published.Messages + topic.Consumed.Messages
+		if size := topic.Published.Size; size == nil || size.Samples != 64 {
+			t.Errorf("%s: sizes %+v, want a full reservoir", topic.Topic, size)
+		}
+	}
+	if total != publishers*messages {
+		t.Errorf("%d messages counted, want %d", total, publishers*messages)
+	}
+}
+
+// TestTopicStatsObserveAllocations fails when sampling a message of a known
+// topic allocates, which would show on every publish
+func TestTopicStatsObserveAllocations(t *testing.T) {
+	stats, _ := testStats(config.KafkaStatsConfig{ReservoirSize: 1024, RateWindow: time.Minute, TopTopics: 10, MaxTopics: 100})
+	stats.observe(directionPublished, "app.form.response.created", "form.response.created", 512)
+
+	allocs := testing.AllocsPerRun(1000, func() {
+		stats.observe(directionPublished, "app.form.response.created", "form.response.created", 512)
+	})
+	if allocs > 0 {
+		t.Errorf("%.0f allocs per message, want none", allocs)
+	}
+}
+
+func BenchmarkTopicStatsObserve(b *testing.B) {
+	stats, _ := testStats(config.KafkaStatsConfig{ReservoirSize: 1024, RateWindow: time.Minute, TopTopics: 10, MaxTopics: 1000})
+	topics := make([]string, 16)
+	for i := range topics {
+		topics[i] = fmt.Sprintf("app.topic-%d", i)
+	}
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			stats.observe(i%2, topics[i%len(topics)], "form.response.created", 256+i%4096)
+			i++
+		}
+	})
+}
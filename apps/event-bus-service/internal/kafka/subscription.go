@@ -0,0 +1,163 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+)
+
+// Subscription is an ephemeral consumer receiving the messages published to
+// its topics after it joined. It never commits offsets.
+type Subscription interface {
+	// Messages delivers the consumed messages and is closed once the
+	// subscription stops
+	Messages() <-chan *Message
+	// Close leaves and deletes the consumer group
+	Close() error
+}
+
+// subscriptionBuffer is the number of messages a subscription holds for a
+// slow reader before its partitions stop being fetched
+const subscriptionBuffer = 64
+
+// subscription implements Subscription with a consumer group of its own
+type subscription struct {
+	client    *Client
+	group     sarama.ConsumerGroup
+	groupID   string
+	messages  chan *Message
+	cancel    context.CancelFunc
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Subscribe joins the consumer group groupID, which must be unique to the
+// subscription, reading topics from their latest offsets
+func (c *Client) Subscribe(ctx context.Context, groupID string, topics []string) (Subscription, error) {
+	if c.closed {
+		return nil, fmt.Errorf("kafka client is closed")
+	}
+	if len(topics) == 0 {
+		return nil, fmt.Errorf("no topics specified for subscription")
+	}
+
+	kafkaConfig, err := c.createKafkaConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka config: %w", err)
+	}
+	kafkaConfig.Consumer.Offsets.Initial = sarama.OffsetNewest
+	kafkaConfig.Consumer.Offsets.AutoCommit.Enable = false
+	kafkaConfig.Consumer.Return.Errors = false
+	// The group is deleted on close, which static members never leave
+	kafkaConfig.Consumer.Group.InstanceId = ""
+
+	// Subscriptions stay on the cluster consumers were on when they started
+	group, err := sarama.NewConsumerGroup(c.consumerBrokers(), groupID, kafkaConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consumer group: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s := &subscription{
+		client:   c,
+		group:    group,
+		groupID:  groupID,
+		messages: make(chan *Message, subscriptionBuffer),
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+
+	go func() {
+		defer close(s.done)
+		// Consume returns once every claim has stopped sending, so the
+		// channel can be closed after the loop
+		defer close(s.messages)
+
+		handler := &subscriptionHandler{subscription: s}
+		for {
+			if err := group.Consume(ctx, topics, handler); err != nil && ctx.Err() == nil {
+				c.logger.Warn("Subscription consumer error", zap.String("group_id", groupID), zap.Error(err))
+				select {
+				case <-time.After(time.Second):
+				case <-ctx.Done():
+				}
+			}
+			if ctx.Err() != nil {
+				return
+			}
+		}
+	}()
+
+	c.logger.Debug("Subscription started", zap.String("group_id", groupID), zap.Strings("topics", topics))
+	return s, nil
+}
+
+// Messages implements Subscription
+func (s *subscription) Messages() <-chan *Message {
+	return s.messages
+}
+
+// Close implements Subscription. The group has no committed offsets, so it
+// is deleted as soon as its member has left.
+func (s *subscription) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		s.cancel()
+		<-s.done
+		if err = s.group.Close(); err != nil {
+			err = fmt.Errorf("failed to close subscription: %w", err)
+			return
+		}
+		if deleteErr := s.client.admin.DeleteConsumerGroup(s.groupID); deleteErr != nil {
+			s.client.logger.Debug("Failed to delete subscription consumer group",
+				zap.String("group_id", s.groupID),
+				zap.Error(deleteErr))
+		}
+	})
+	return err
+}
+
+// subscriptionHandler implements sarama.ConsumerGroupHandler for subscriptions
+type subscriptionHandler struct {
+	subscription *subscription
+}
+
+// Setup is run before the subscription starts consuming
+func (h *subscriptionHandler) Setup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// Cleanup is run after the subscription stops consuming
+func (h *subscriptionHandler) Cleanup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// ConsumeClaim delivers the messages of a partition to the subscription
+func (h *subscriptionHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for {
+		select {
+		case kafkaMessage, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+			message, err := convertKafkaMessage(kafkaMessage)
+			if err != nil {
+				h.subscription.client.logger.Debug("Skipping undecodable message",
+					zap.String("topic", kafkaMessage.Topic),
+					zap.Error(err))
+				continue
+			}
+			select {
+			case h.subscription.messages <- message:
+			case <-session.Context().Done():
+				return nil
+			}
+		case <-session.Context().Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+)
+
+// ErrTopicNotFound is returned for topics that do not exist in the cluster
+var ErrTopicNotFound = errors.New("topic not found")
+
+// TopicInfo describes a topic as it exists in the cluster
+type TopicInfo struct {
+	Name              string            `json:"name"`
+	Internal          bool              `json:"internal"`
+	ReplicationFactor int               `json:"replication_factor"`
+	Partitions        []PartitionInfo   `json:"partitions"`
+	Config            map[string]string `json:"config"`
+}
+
+// PartitionInfo describes a partition of a topic
+type PartitionInfo struct {
+	ID              int32   `json:"id"`
+	Leader          int32   `json:"leader"`
+	Replicas        []int32 `json:"replicas"`
+	InSyncReplicas  []int32 `json:"in_sync_replicas"`
+	OfflineReplicas []int32 `json:"offline_replicas,omitempty"`
+}
+
+// ReconcileTopics ensures every topic declared by name in the configuration
+// exists with its declared partitions and policies
+func (c *Client) ReconcileTopics(ctx context.Context) error {
+	var errs []error
+	for _, declared := range c.config.Kafka.Topics.Specs {
+		if declared.Name == "" {
+			continue
+		}
+		if err := c.EnsureTopic(ctx, c.config.Kafka.Topics.SpecFor(declared.Name)); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		c.markProvisioned(declared.Name)
+	}
+	return errors.Join(errs...)
+}
+
+// EnsureTopic creates the topic of spec or brings an existing one in line
+// with it. Partitions are added when the spec asks for more; Kafka cannot
+// remove partitions, so asking for fewer is an error.
+func (c *Client) EnsureTopic(ctx context.Context, spec config.TopicSpec) error {
+	if c.closed {
+		return fmt.Errorf("kafka client is closed")
+	}
+	if spec.Name == "" {
+		return fmt.Errorf("topic spec has no name")
+	}
+
+	metadata, err := c.describeTopic(spec.Name)
+	if errors.Is(err, ErrTopicNotFound) {
+		return c.createTopic(spec)
+	}
+	if err != nil {
+		return err
+	}
+
+	current := int32(len(metadata.Partitions))
+	switch {
+	case spec.Partitions > current:
+		if err := c.admin.CreatePartitions(spec.Name, spec.Partitions, nil, false); err != nil {
+			return fmt.Errorf("failed to increase the partitions of topic %s from %d to %d: %w", spec.Name, current, spec.Partitions, err)
+		}
+		c.logger.Info("Topic partitions increased",
+			zap.String("topic", spec.Name),
+			zap.Int32("from", current),
+			zap.Int32("to", spec.Partitions))
+	case spec.Partitions > 0 && spec.Partitions < current:
+		return fmt.Errorf("topic %s has %d partitions but its spec declares %d: Kafka cannot decrease the partitions of a topic", spec.Name, current, spec.Partitions)
+	}
+
+	if rf := replicationFactor(metadata); spec.ReplicationFactor > 0 && rf != int(spec.ReplicationFactor) {
+		c.logger.Warn("Topic replication factor differs from its spec and is not changed automatically",
+			zap.String("topic", spec.Name),
+			zap.Int("current", rf),
+			zap.Int16("declared", spec.ReplicationFactor))
+	}
+
+	return c.alignTopicConfig(spec)
+}
+
+// DescribeTopic returns the partitions and configuration of a topic
+func (c *Client) DescribeTopic(ctx context.Context, name string) (*TopicInfo, error) {
+	if c.closed {
+		return nil, fmt.Errorf("kafka client is closed")
+	}
+
+	metadata, err := c.describeTopic(name)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := c.admin.DescribeConfig(sarama.ConfigResource{Type: sarama.TopicResource, Name: name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe the configuration of topic %s: %w", name, err)
+	}
+
+	info := &TopicInfo{
+		Name:              name,
+		Internal:          metadata.IsInternal,
+		ReplicationFactor: replicationFactor(metadata),
+		Partitions:        make([]PartitionInfo, 0, len(metadata.Partitions)),
+		Config:            make(map[string]string, len(entries)),
+	}
+	for _, p := range metadata.Partitions {
+		info.Partitions = append(info.Partitions, PartitionInfo{
+			ID:              p.ID,
+			Leader:          p.Leader,
+			Replicas:        p.Replicas,
+			InSyncReplicas:  p.Isr,
+			OfflineReplicas: p.OfflineReplicas,
+		})
+	}
+	sort.Slice(info.Partitions, func(i, j int) bool { return info.Partitions[i].ID < info.Partitions[j].ID })
+	for _, entry := range entries {
+		if !entry.Sensitive {
+			info.Config[entry.Name] = entry.Value
+		}
+	}
+	return info, nil
+}
+
+// ensureProvisioned ensures a topic exists before its first message is
+// published. Topics are checked once; later publishes skip the admin calls.
+func (c *Client) ensureProvisioned(ctx context.Context, topic string) error {
+	c.topicsMutex.Lock()
+	defer c.topicsMutex.Unlock()
+
+	if c.provisioned[topic] {
+		return nil
+	}
+	if err := c.EnsureTopic(ctx, c.config.Kafka.Topics.SpecFor(topic)); err != nil {
+		return err
+	}
+	c.provisioned[topic] = true
+	return nil
+}
+
+func (c *Client) markProvisioned(topic string) {
+	c.topicsMutex.Lock()
+	defer c.topicsMutex.Unlock()
+	c.provisioned[topic] = true
+}
+
+func (c *Client) describeTopic(name string) (*sarama.TopicMetadata, error) {
+	metadata, err := c.admin.DescribeTopics([]string{name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe topic %s: %w", name, err)
+	}
+	for _, topic := range metadata {
+		if topic.Name != name {
+			continue
+		}
+		switch {
+		case errors.Is(topic.Err, sarama.ErrUnknownTopicOrPartition):
+			return nil, fmt.Errorf("%w: %s", ErrTopicNotFound, name)
+		case topic.Err != sarama.ErrNoError:
+			return nil, fmt.Errorf("failed to describe topic %s: %w", name, topic.Err)
+		}
+		return topic, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrTopicNotFound, name)
+}
+
+func (c *Client) createTopic(spec config.TopicSpec) error {
+	detail := &sarama.TopicDetail{
+		NumPartitions:     spec.Partitions,
+		ReplicationFactor: spec.ReplicationFactor,
+		ConfigEntries:     make(map[string]*string),
+	}
+	for key, value := range topicConfigEntries(spec) {
+		value := value
+		detail.ConfigEntries[key] = &value
+	}
+
+	err := c.admin.CreateTopic(spec.Name, detail, false)
+	var topicErr *sarama.TopicError
+	if errors.As(err, &topicErr) && topicErr.Err == sarama.ErrTopicAlreadyExists {
+		// Created concurrently by another instance
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create topic %s: %w", spec.Name, err)
+	}
+
+	c.logger.Info("Topic created",
+		zap.String("topic", spec.Name),
+		zap.Int32("partitions", spec.Partitions),
+		zap.Int16("replication_factor", spec.ReplicationFactor),
+		zap.Int64("retention_ms", spec.RetentionMs),
+		zap.String("cleanup_policy", spec.CleanupPolicy))
+	return nil
+}
+
+// alignTopicConfig updates the retention and cleanup policy of an existing
+// topic when they differ from its spec
+func (c *Client) alignTopicConfig(spec config.TopicSpec) error {
+	wanted := topicConfigEntries(spec)
+	if len(wanted) == 0 {
+		return nil
+	}
+
+	entries, err := c.admin.DescribeConfig(sarama.ConfigResource{Type: sarama.TopicResource, Name: spec.Name})
+	if err != nil {
+		return fmt.Errorf("failed to describe the configuration of topic %s: %w", spec.Name, err)
+	}
+	current := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		current[entry.Name] = entry.Value
+	}
+
+	changes := make(map[string]sarama.IncrementalAlterConfigsEntry)
+	for key, value := range wanted {
+		if current[key] != value {
+			value := value
+			changes[key] = sarama.IncrementalAlterConfigsEntry{Operation: sarama.IncrementalAlterConfigsOperationSet, Value: &value}
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	if err := c.admin.IncrementalAlterConfig(sarama.TopicResource, spec.Name, changes, false); err != nil {
+		return fmt.Errorf("failed to update the configuration of topic %s: %w", spec.Name, err)
+	}
+
+	c.logger.Info("Topic configuration updated",
+		zap.String("topic", spec.Name),
+		zap.Any("config", wanted))
+	return nil
+}
+
+// topicConfigEntries returns the topic configuration a spec sets
+func topicConfigEntries(spec config.TopicSpec) map[string]string {
+	entries := make(map[string]string)
+	if spec.RetentionMs != 0 {
+		entries["retention.ms"] = strconv.FormatInt(spec.RetentionMs, 10)
+	}
+	if spec.CleanupPolicy != "" {
+		entries["cleanup.policy"] = spec.CleanupPolicy
+	}
+	return entries
+}
+
+func replicationFactor(metadata *sarama.TopicMetadata) int {
+	if len(metadata.Partitions) == 0 {
+		return 0
+	}
+	return len(metadata.Partitions[0].Replicas)
+}
@@ -0,0 +1,206 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+)
+
+// fakeAdmin keeps topics in memory. Methods it does not override panic.
+type fakeAdmin struct {
+	sarama.ClusterAdmin
+	topics   map[string]*fakeTopic
+	describe int
+}
+
+type fakeTopic struct {
+	partitions        int32
+	replicationFactor int16
+	config            map[string]string
+}
+
+func (a *fakeAdmin) DescribeTopics(names []string) ([]*sarama.TopicMetadata, error) {
+	a.describe++
+	var metadata []*sarama.TopicMetadata
+	for _, name := range names {
+		topic, ok := a.topics[name]
+		if !ok {
+			metadata = append(metadata, &sarama.TopicMetadata{Name: name, Err: sarama.ErrUnknownTopicOrPartition})
+			continue
+		}
+		meta := &sarama.TopicMetadata{Name: name}
+		for id := topic.partitions - 1; id >= 0; id-- {
+			replicas := make([]int32, topic.replicationFactor)
+			for i := range replicas {
+				replicas[i] = int32(i + 1)
+			}
+			meta.Partitions = append(meta.Partitions, &sarama.PartitionMetadata{ID: id, Leader: 1, Replicas: replicas, Isr: replicas})
+		}
+		metadata = append(metadata, meta)
+	}
+	return metadata, nil
+}
+
+func (a *fakeAdmin) CreateTopic(name string, detail *sarama.TopicDetail, validateOnly bool) error {
+	if _, ok := a.topics[name]; ok {
+		return &sarama.TopicError{Err: sarama.ErrTopicAlreadyExists}
+	}
+	topic := &fakeTopic{partitions: detail.NumPartitions, replicationFactor: detail.ReplicationFactor, config: map[string]string{}}
+	for key, value := range detail.ConfigEntries {
+		topic.config[key] = *value
+	}
+	a.topics[name] = topic
+	return nil
+}
+
+func (a *fakeAdmin) CreatePartitions(name string, count int32, assignment [][]int32, validateOnly bool) error {
+	a.topics[name].partitions = count
+	return nil
+}
+
+func (a *fakeAdmin) DescribeConfig(resource sarama.ConfigResource) ([]sarama.ConfigEntry, error) {
+	var entries []sarama.ConfigEntry
+	for key, value := range a.topics[resource.Name].config {
+		entries = append(entries, sarama.ConfigEntry{Name: key, Value: value})
+	}
+	entries = append(entries, sarama.ConfigEntry{Name: "sasl.jaas.config", Value: "secret", Sensitive: true})
+	return entries, nil
+}
+
+func (a *fakeAdmin) IncrementalAlterConfig(resourceType sarama.ConfigResourceType, name string, entries map[string]sarama.IncrementalAlterConfigsEntry, validateOnly bool) error {
+	for key, entry := range entries {
+		a.topics[name].config[key] = *entry.Value
+	}
+	return nil
+}
+
+func newTopicsClient(topics config.KafkaTopicsConfig, existing map[string]*fakeTopic) (*Client, *fakeAdmin) {
+	admin := &fakeAdmin{topics: existing}
+	cfg := &config.Config{Kafka: config.KafkaConfig{Topics: topics}}
+	return &Client{config: cfg, logger: zap.NewNop(), admin: admin, provisioned: make(map[string]bool)}, admin
+}
+
+func TestEnsureTopicCreatesMissingTopic(t *testing.T) {
+	client, admin := newTopicsClient(config.KafkaTopicsConfig{}, map[string]*fakeTopic{})
+
+	spec := config.TopicSpec{Name: "app.form.created", Partitions: 6, ReplicationFactor: 3, RetentionMs: 86400000, CleanupPolicy: "delete"}
+	if err := client.EnsureTopic(context.Background(), spec); err != nil {
+		t.Fatal(err)
+	}
+	want := &fakeTopic{partitions: 6, replicationFactor: 3, config: map[string]string{"retention.ms": "86400000", "cleanup.policy": "delete"}}
+	if got := admin.topics["app.form.created"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("created %+v, want %+v", got, want)
+	}
+}
+
+func TestEnsureTopicUpdatesExistingTopic(t *testing.T) {
+	client, admin := newTopicsClient(config.KafkaTopicsConfig{}, map[string]*fakeTopic{
+		"app.form.created": {partitions: 3, replicationFactor: 1, config: map[string]string{"retention.ms": "604800000", "cleanup.policy": "delete"}},
+	})
+
+	spec := config.TopicSpec{Name: "app.form.created", Partitions: 6, RetentionMs: 86400000, CleanupPolicy: "delete"}
+	if err := client.EnsureTopic(context.Background(), spec); err != nil {
+		t.Fatal(err)
+	}
+	topic := admin.topics["app.form.created"]
+	if topic.partitions != 6 {
+		t.Errorf("partitions = %d, want 6", topic.partitions)
+	}
+	if topic.config["retention.ms"] != "86400000" {
+		t.Errorf("retention.ms = %s, want 86400000", topic.config["retention.ms"])
+	}
+}
+
+func TestEnsureTopicRejectsFewerPartitions(t *testing.T) {
+	client, admin := newTopicsClient(config.KafkaTopicsConfig{}, map[string]*fakeTopic{
+		"app.form.created": {partitions: 6, replicationFactor: 1, config: map[string]string{}},
+	})
+
+	err := client.EnsureTopic(context.Background(), config.TopicSpec{Name: "app.form.created", Partitions: 3})
+	if err == nil || !strings.Contains(err.Error(), "cannot decrease the partitions") {
+		t.Fatalf("expected a partition decrease error, got %v", err)
+	}
+	if admin.topics["app.form.created"].partitions != 6 {
+		t.Error("partitions were changed")
+	}
+}
+
+func TestReconcileTopics(t *testing.T) {
+	topics := config.KafkaTopicsConfig{
+		Defaults: config.TopicSpec{Partitions: 3, ReplicationFactor: 1, CleanupPolicy: "delete"},
+		Specs: []config.TopicSpec{
+			{Pattern: "app.form.*", Partitions: 6},
+			{Name: "dead-letter-queue", Partitions: 1},
+			{Name: "app.form.snapshots", Partitions: 2, CleanupPolicy: "compact"},
+		},
+	}
+	client, admin := newTopicsClient(topics, map[string]*fakeTopic{
+		"app.form.snapshots": {partitions: 4, replicationFactor: 1, config: map[string]string{}},
+	})
+
+	err := client.ReconcileTopics(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "app.form.snapshots has 4 partitions") {
+		t.Errorf("expected the snapshots topic to be reported, got %v", err)
+	}
+	if topic, ok := admin.topics["dead-letter-queue"]; !ok || topic.partitions != 1 {
+		t.Errorf("dead-letter-queue not created: %+v", topic)
+	}
+	if len(admin.topics) != 2 {
+		t.Errorf("pattern specs must not create topics, got %d topics", len(admin.topics))
+	}
+}
+
+func TestEnsureProvisionedChecksOnce(t *testing.T) {
+	topics := config.KafkaTopicsConfig{
+		AutoProvision: true,
+		Defaults:      config.TopicSpec{Partitions: 3, ReplicationFactor: 1},
+		Specs:         []config.TopicSpec{{Pattern: "app.form.*", Partitions: 6}},
+	}
+	client, admin := newTopicsClient(topics, map[string]*fakeTopic{})
+
+	for i := 0; i < 3; i++ {
+		if err := client.ensureProvisioned(context.Background(), "app.form.created"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if admin.describe != 1 {
+		t.Errorf("topic described %d times, want 1", admin.describe)
+	}
+	if topic := admin.topics["app.form.created"]; topic == nil || topic.partitions != 6 {
+		t.Errorf("topic not provisioned from its pattern spec: %+v", topic)
+	}
+}
+
+func TestDescribeTopic(t *testing.T) {
+	client, _ := newTopicsClient(config.KafkaTopicsConfig{}, map[string]*fakeTopic{
+		"app.form.created": {partitions: 2, replicationFactor: 2, config: map[string]string{"cleanup.policy": "delete"}},
+	})
+
+	info, err := client.DescribeTopic(context.Background(), "app.form.created")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &TopicInfo{
+		Name:              "app.form.created",
+		ReplicationFactor: 2,
+		Partitions: []PartitionInfo{
+			{ID: 0, Leader: 1, Replicas: []int32{1, 2}, InSyncReplicas: []int32{1, 2}},
+			{ID: 1, Leader: 1, Replicas: []int32{1, 2}, InSyncReplicas: []int32{1, 2}},
+		},
+		Config: map[string]string{"cleanup.policy": "delete"},
+	}
+	if !reflect.DeepEqual(info, want) {
+		t.Errorf("DescribeTopic = %+v, want %+v", info, want)
+	}
+
+	if _, err := client.DescribeTopic(context.Background(), "app.unknown"); !errors.Is(err, ErrTopicNotFound) {
+		t.Errorf("expected ErrTopicNotFound, got %v", err)
+	}
+}
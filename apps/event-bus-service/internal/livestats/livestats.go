@@ -0,0 +1,169 @@
+// Package livestats pushes the submissions of forms to the dashboards of
+// their owners as they happen. The publisher counts the form.response.created
+// events of each batch per form and publishes one response_count update per
+// form to the Redis pub/sub channel form:{id}:stats, which the collaboration
+// service relays to the owners watching the dashboard of the form. Updates
+// are deltas: subscribers add them to the total they fetched on join. Test
+// submissions are not counted.
+package livestats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/projections"
+)
+
+// UpdateType is the type of the updates published
+const UpdateType = "response_count"
+
+// Channel returns the pub/sub channel of the updates of a form
+func Channel(formID string) string {
+	return "form:" + formID + ":stats"
+}
+
+// Update is the update published for the submissions of a form in a batch
+type Update struct {
+	Type   string `json:"type"`
+	FormID string `json:"form_id"`
+	// Count is the number of submissions since the previous update
+	Count int64 `json:"count"`
+	// At is when the last of the submissions was made
+	At time.Time `json:"at"`
+}
+
+// Publisher publishes messages to pub/sub channels
+type Publisher interface {
+	Publish(ctx context.Context, channel string, message []byte) error
+}
+
+// Counter publishes the submissions of forms. It implements
+// kafka.BatchConsumerHandler.
+type Counter struct {
+	publisher Publisher
+	metrics   *Metrics
+	logger    *zap.Logger
+
+	topics  []string
+	groupID string
+	now     func() time.Time
+}
+
+// New creates a counter publishing through publisher
+func New(cfg config.LiveStatsConfig, publisher Publisher, metrics *Metrics, logger *zap.Logger) *Counter {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Counter{
+		publisher: publisher,
+		metrics:   metrics,
+		logger:    logger,
+		topics:    cfg.Topics,
+		groupID:   cfg.GroupID,
+		now:       time.Now,
+	}
+}
+
+// GetTopics returns the topics the counter consumes
+func (c *Counter) GetTopics() []string {
+	return c.topics
+}
+
+// GetGroupID returns the consumer group the counter commits offsets in
+func (c *Counter) GetGroupID() string {
+	return c.groupID
+}
+
+// HandleBatch publishes one update per form submitted to in the batch. Live
+// counts are best effort: updates that fail to publish are logged and
+// dropped rather than redelivering the batch, since dashboards resync their
+// total when they are opened.
+func (c *Counter) HandleBatch(ctx context.Context, messages []*kafka.Message) error {
+	updates := make(map[string]*Update)
+	for _, message := range messages {
+		if message.EventType != projections.ResponseCreatedEventType {
+			c.metrics.Events.WithLabelValues("skipped").Inc()
+			continue
+		}
+		event, err := projections.ParseResponseEvent(message)
+		if err != nil {
+			c.logger.Warn("Dropping invalid response event", zap.String("event_id", message.ID), zap.Error(err))
+			c.metrics.Events.WithLabelValues("invalid").Inc()
+			continue
+		}
+		if event.Test {
+			c.metrics.Events.WithLabelValues("test").Inc()
+			continue
+		}
+
+		submitted := event.SubmittedAt
+		if submitted.IsZero() || submitted.After(c.now()) {
+			submitted = c.now()
+		}
+		update, ok := updates[event.FormID]
+		if !ok {
+			update = &Update{Type: UpdateType, FormID: event.FormID}
+			updates[event.FormID] = update
+		}
+		update.Count++
+		if submitted.After(update.At) {
+			update.At = submitted
+		}
+	}
+
+	formIDs := make([]string, 0, len(updates))
+	for formID := range updates {
+		formIDs = append(formIDs, formID)
+	}
+	sort.Strings(formIDs)
+
+	for _, formID := range formIDs {
+		update := updates[formID]
+		if err := c.publish(ctx, update); err != nil {
+			c.logger.Warn("Failed to publish the live count of a form", zap.String("form_id", formID), zap.Error(err))
+			c.metrics.Events.WithLabelValues("publish_failed").Add(float64(update.Count))
+			continue
+		}
+		c.metrics.Events.WithLabelValues("published").Add(float64(update.Count))
+		c.metrics.Updates.Inc()
+	}
+	return nil
+}
+
+func (c *Counter) publish(ctx context.Context, update *Update) error {
+	message, err := json.Marshal(update)
+	if err != nil {
+		return fmt.Errorf("failed to encode update: %w", err)
+	}
+	return c.publisher.Publish(ctx, Channel(update.FormID), message)
+}
+
+// Metrics contains the Prometheus metrics of the counter
+type Metrics struct {
+	Events  *prometheus.CounterVec
+	Updates prometheus.Counter
+}
+
+// NewMetrics creates the counter metrics and registers them with reg
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		Events: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "eventbus_live_stats_events_total",
+			Help: "Total number of events consumed by the live stats publisher, by outcome",
+		}, []string{"status"}),
+		Updates: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "eventbus_live_stats_updates_total",
+			Help: "Total number of response count updates published",
+		}),
+	}
+	reg.MustRegister(m.Events, m.Updates)
+	return m
+}
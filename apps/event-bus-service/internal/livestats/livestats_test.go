@@ -0,0 +1,89 @@
+package livestats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/projections"
+)
+
+// recordingPublisher records the messages published to each channel
+type recordingPublisher struct {
+	messages map[string][]Update
+	fail     string
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, channel string, message []byte) error {
+	if channel == p.fail {
+		return errors.New("connection refused")
+	}
+	var update Update
+	if err := json.Unmarshal(message, &update); err != nil {
+		return err
+	}
+	p.messages[channel] = append(p.messages[channel], update)
+	return nil
+}
+
+func responses(formID string, n int, at time.Time) []*kafka.Message {
+	messages := make([]*kafka.Message, n)
+	for i := range messages {
+		data, _ := json.Marshal(map[string]interface{}{
+			"response_id":  fmt.Sprintf("r-%s-%d", formID, i),
+			"form_id":      formID,
+			"answers_hash": "sha256:0",
+			"submitted_at": at.Add(time.Duration(i) * time.Second),
+		})
+		messages[i] = &kafka.Message{ID: fmt.Sprintf("e-%s-%d", formID, i), EventType: projections.ResponseCreatedEventType, Data: json.RawMessage(data)}
+	}
+	return messages
+}
+
+func TestCounterPublishesOneUpdatePerForm(t *testing.T) {
+	publisher := &recordingPublisher{messages: map[string][]Update{}, fail: Channel("form-3")}
+	metrics := NewMetrics(prometheus.NewRegistry())
+	c := New(config.LiveStatsConfig{}, publisher, metrics, nil)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now.Add(time.Hour) }
+
+	var batch []*kafka.Message
+	batch = append(batch, responses("form-1", 5, now)...)
+	batch = append(batch, responses("form-2", 2, now)...)
+	batch = append(batch, responses("form-3", 1, now)...)
+	batch = append(batch, &kafka.Message{ID: "other", EventType: "form.published"})
+	// Test submissions are not counted
+	testData, _ := json.Marshal(map[string]interface{}{
+		"response_id": "r-test", "form_id": "form-1", "answers_hash": "sha256:0", "is_test": true,
+	})
+	batch = append(batch, &kafka.Message{ID: "e-test", EventType: projections.ResponseCreatedEventType, Data: json.RawMessage(testData)})
+	if err := c.HandleBatch(context.Background(), batch); err != nil {
+		t.Fatalf("a failed publish must not fail the batch: %v", err)
+	}
+
+	got := publisher.messages[Channel("form-1")]
+	want := Update{Type: UpdateType, FormID: "form-1", Count: 5, At: now.Add(4 * time.Second)}
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("form-1 updates = %+v, want %+v", got, want)
+	}
+	if got := publisher.messages["form:form-2:stats"]; len(got) != 1 || got[0].Count != 2 {
+		t.Errorf("form-2 updates = %+v, want a count of 2", got)
+	}
+	if got := testutil.ToFloat64(metrics.Events.WithLabelValues("publish_failed")); got != 1 {
+		t.Errorf("failed events = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(metrics.Events.WithLabelValues("test")); got != 1 {
+		t.Errorf("test events = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(metrics.Updates); got != 2 {
+		t.Errorf("updates = %v, want 2", got)
+	}
+}
@@ -0,0 +1,22 @@
+package livestats
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisPublisher is a Publisher of Redis pub/sub channels
+type RedisPublisher struct {
+	client redis.UniversalClient
+}
+
+// NewRedisPublisher creates a publisher of the channels of client
+func NewRedisPublisher(client redis.UniversalClient) *RedisPublisher {
+	return &RedisPublisher{client: client}
+}
+
+// Publish publishes message to channel
+func (p *RedisPublisher) Publish(ctx context.Context, channel string, message []byte) error {
+	return p.client.Publish(ctx, channel, message).Err()
+}
@@ -0,0 +1,278 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+)
+
+// Chat services notification channels post to
+const (
+	ChannelTypeSlack = "slack"
+	ChannelTypeTeams = "teams"
+)
+
+// ChannelTestEventType asks for a test message to a channel of a form
+const ChannelTestEventType = "form.notification.channel_test"
+
+// errWebhookRejected marks webhook answers that retrying won't change, such
+// as a deleted channel or a revoked webhook
+var errWebhookRejected = errors.New("webhook rejected the message")
+
+// ChannelMessage is the compact card posted to a chat channel
+type ChannelMessage struct {
+	Title string
+	Text  string
+	// Facts are the figures listed under the text
+	Facts     []Fact
+	Link      string
+	LinkLabel string
+}
+
+// Fact is a labelled value of a card
+type Fact struct {
+	Name  string
+	Value string
+}
+
+// NotificationChannel posts the message rendered for an event to a chat
+// channel
+type NotificationChannel interface {
+	Send(ctx context.Context, event *kafka.Message, message ChannelMessage) error
+}
+
+// channelFactories create the NotificationChannel of each channel type for
+// a webhook URL
+var channelFactories = map[string]func(webhookURL string, client *http.Client) NotificationChannel{
+	ChannelTypeSlack: func(webhookURL string, client *http.Client) NotificationChannel {
+		return NewSlackChannel(webhookURL, client)
+	},
+	ChannelTypeTeams: func(webhookURL string, client *http.Client) NotificationChannel {
+		return NewTeamsChannel(webhookURL, client)
+	},
+}
+
+// newChannelClient returns the client posting to webhooks. It does not
+// follow redirects: the form service only accepts webhooks of the chat
+// services, and a redirect could lead anywhere.
+func newChannelClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// SlackChannel posts to a Slack incoming webhook, as Block Kit blocks
+type SlackChannel struct {
+	webhookURL string
+	client     *http.Client
+}
+
+// NewSlackChannel creates a channel posting to the Slack webhook at webhookURL
+func NewSlackChannel(webhookURL string, client *http.Client) *SlackChannel {
+	return &SlackChannel{webhookURL: webhookURL, client: client}
+}
+
+// Send posts the message as a header, its text and facts and a link button
+func (s *SlackChannel) Send(ctx context.Context, _ *kafka.Message, message ChannelMessage) error {
+	type text struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	type block struct {
+		Type     string        `json:"type"`
+		Text     *text         `json:"text,omitempty"`
+		Fields   []text        `json:"fields,omitempty"`
+		Elements []interface{} `json:"elements,omitempty"`
+	}
+
+	blocks := []block{
+		{Type: "header", Text: &text{Type: "plain_text", Text: truncate(message.Title, 150)}},
+		{Type: "section", Text: &text{Type: "mrkdwn", Text: slackEscape(message.Text)}},
+	}
+	// Sections hold up to 10 fields
+	for start := 0; start < len(message.Facts); start += 10 {
+		end := start + 10
+		if end > len(message.Facts) {
+			end = len(message.Facts)
+		}
+		section := block{Type: "section"}
+		for _, fact := range message.Facts[start:end] {
+			section.Fields = append(section.Fields, text{
+				Type: "mrkdwn",
+				Text: "*" + slackEscape(fact.Name) + "*\n" + slackEscape(fact.Value),
+			})
+		}
+		blocks = append(blocks, section)
+	}
+	if message.Link != "" {
+		blocks = append(blocks, block{Type: "actions", Elements: []interface{}{map[string]interface{}{
+			"type": "button",
+			"text": text{Type: "plain_text", Text: message.LinkLabel},
+			"url":  message.Link,
+		}}})
+	}
+
+	return postWebhook(ctx, s.client, s.webhookURL, map[string]interface{}{
+		// text is the fallback of notifications and clients without blocks
+		"text":   slackEscape(message.Title),
+		"blocks": blocks,
+	})
+}
+
+// TeamsChannel posts to a Microsoft Teams incoming webhook, as a connector
+// card
+type TeamsChannel struct {
+	webhookURL string
+	client     *http.Client
+}
+
+// NewTeamsChannel creates a channel posting to the Teams webhook at webhookURL
+func NewTeamsChannel(webhookURL string, client *http.Client) *TeamsChannel {
+	return &TeamsChannel{webhookURL: webhookURL, client: client}
+}
+
+// Send posts the message as a MessageCard with its facts and a link action
+func (t *TeamsChannel) Send(ctx context.Context, _ *kafka.Message, message ChannelMessage) error {
+	type fact struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	facts := make([]fact, 0, len(message.Facts))
+	for _, f := range message.Facts {
+		facts = append(facts, fact{Name: teamsEscape(f.Name), Value: teamsEscape(f.Value)})
+	}
+
+	card := map[string]interface{}{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"summary":    message.Title,
+		"themeColor": "0076D7",
+		"title":      teamsEscape(message.Title),
+		"text":       teamsEscape(message.Text),
+	}
+	if len(facts) > 0 {
+		card["sections"] = []interface{}{map[string]interface{}{"facts": facts}}
+	}
+	if message.Link != "" {
+		card["potentialAction"] = []interface{}{map[string]interface{}{
+			"@type":   "OpenUri",
+			"name":    message.LinkLabel,
+			"targets": []interface{}{map[string]string{"os": "default", "uri": message.Link}},
+		}}
+	}
+	return postWebhook(ctx, t.client, t.webhookURL, card)
+}
+
+// postWebhook posts payload as JSON. Rate limits and server errors may be
+// retried; other failures wrap errWebhookRejected. Errors never include the
+// webhook URL, which is a credential.
+func postWebhook(ctx context.Context, client *http.Client, webhookURL string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: invalid webhook URL", errWebhookRejected)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to post to webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	answer, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("webhook answered %d: %s", resp.StatusCode, strings.TrimSpace(string(answer)))
+	default:
+		return fmt.Errorf("%w: %d %s", errWebhookRejected, resp.StatusCode, strings.TrimSpace(string(answer)))
+	}
+}
+
+// subscribes reports whether the channel posts events of eventType
+func (c Channel) subscribes(eventType string) bool {
+	for _, subscribed := range c.EventTypes {
+		if subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// location returns the time zone of the channel, UTC when unknown
+func (c Channel) location() *time.Location {
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// quiet reports whether t falls in the quiet hours of the channel, in its
+// time zone. Quiet hours ending before they start span midnight.
+func (c Channel) quiet(t time.Time) bool {
+	start, okStart := minuteOfDay(c.QuietHoursStart)
+	end, okEnd := minuteOfDay(c.QuietHoursEnd)
+	if !okStart || !okEnd || start == end {
+		return false
+	}
+	local := t.In(c.location())
+	now := local.Hour()*60 + local.Minute()
+	if start < end {
+		return now >= start && now < end
+	}
+	return now >= start || now < end
+}
+
+// minuteOfDay parses a time of day as HH:MM
+func minuteOfDay(clock string) (int, bool) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// slackEscape escapes the control characters of Slack mrkdwn, so that
+// answers can't mention users or forge links
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// teamsEscape escapes the Markdown of connector cards
+func teamsEscape(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`, "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`, "<", "&lt;", ">", "&gt;", "#", `\#`, "`", "\\`",
+	).Replace(s)
+}
+
+// truncate cuts s to at most max characters
+func truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:max-1]) + "…"
+}
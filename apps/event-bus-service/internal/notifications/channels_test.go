@@ -0,0 +1,246 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+)
+
+// webhook records the payloads posted to it, answering status
+type webhook struct {
+	*httptest.Server
+	status int
+
+	mu       sync.Mutex
+	payloads []string
+}
+
+func newWebhook(t *testing.T, status int) *webhook {
+	t.Helper()
+	w := &webhook{status: status}
+	w.Server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.mu.Lock()
+		w.payloads = append(w.payloads, string(body))
+		w.mu.Unlock()
+		rw.WriteHeader(w.status)
+	}))
+	t.Cleanup(w.Close)
+	return w
+}
+
+func (w *webhook) posts() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.payloads...)
+}
+
+type fixedCounter int
+
+func (c fixedCounter) CountResponses(context.Context, string, time.Time) (int, error) {
+	return int(c), nil
+}
+
+func channelForm(channels ...Channel) memoryForms {
+	return memoryForms{"form-1": {FormID: "form-1", OwnerID: "owner-1", Title: "Feedback", Channels: channels}}
+}
+
+func responseChannel(id, channelType, url string) Channel {
+	return Channel{
+		ID:         id,
+		Type:       channelType,
+		Name:       "#" + id,
+		WebhookURL: url,
+		EventTypes: []string{"form.response.created"},
+		Timezone:   "UTC",
+		Enabled:    true,
+	}
+}
+
+func TestChannelsReceiveCardsWithAnswersRedacted(t *testing.T) {
+	slack, teams, other := newWebhook(t, http.StatusOK), newWebhook(t, http.StatusOK), newWebhook(t, http.StatusOK)
+
+	withAnswers := responseChannel("teams-1", ChannelTypeTeams, teams.URL)
+	withAnswers.IncludeAnswerSummary = true
+	disabled := responseChannel("disabled", ChannelTypeSlack, other.URL)
+	disabled.Enabled = false
+	unsubscribed := responseChannel("published-only", ChannelTypeSlack, other.URL)
+	unsubscribed.EventTypes = []string{"form.published"}
+	forms := channelForm(responseChannel("slack-1", ChannelTypeSlack, slack.URL), withAnswers, disabled, unsubscribed)
+
+	n := newTestNotifier(t, testConfig(), forms, &recordingSender{}, &recordingPublisher{})
+	n.counter = fixedCounter(7)
+	if err := n.HandleBatch(context.Background(), []*kafka.Message{responseMessage("e1", "jane@example.com")}); err != nil {
+		t.Fatal(err)
+	}
+
+	posts := slack.posts()
+	if len(posts) != 1 {
+		t.Fatalf("posted %d Slack messages, want 1", len(posts))
+	}
+	for _, want := range []string{`"New response to Feedback"`, `Responses today*\n7`, `"https://xform.example.com/forms/form-1/responses/response-e1"`, `"View the response"`} {
+		if !strings.Contains(posts[0], want) {
+			t.Errorf("Slack message lacks %s: %s", want, posts[0])
+		}
+	}
+	for _, answer := range []string{"Great service", "Email, Phone", "jane@example.com"} {
+		if strings.Contains(posts[0], answer) {
+			t.Errorf("Slack message shows %q without include_answer_summary: %s", answer, posts[0])
+		}
+	}
+
+	posts = teams.posts()
+	if len(posts) != 1 {
+		t.Fatalf("posted %d Teams messages, want 1", len(posts))
+	}
+	var card struct {
+		Type     string `json:"@type"`
+		Title    string `json:"title"`
+		Sections []struct {
+			Facts []struct{ Name, Value string } `json:"facts"`
+		} `json:"sections"`
+		PotentialAction []struct {
+			Targets []struct{ URI string } `json:"targets"`
+		} `json:"potentialAction"`
+	}
+	if err := json.Unmarshal([]byte(posts[0]), &card); err != nil {
+		t.Fatal(err)
+	}
+	if card.Type != "MessageCard" || card.Title != "New response to Feedback" || len(card.Sections) != 1 ||
+		len(card.PotentialAction) != 1 || card.PotentialAction[0].Targets[0].URI != "https://xform.example.com/forms/form-1/responses/response-e1" {
+		t.Fatalf("Teams card = %s", posts[0])
+	}
+	facts := card.Sections[0].Facts
+	if len(facts) != 4 || facts[0].Name != "Responses today" || facts[1].Value != "Great service" || facts[2].Value != "Email, Phone" {
+		t.Errorf("Teams facts = %+v, want the responses of the day and the answers", facts)
+	}
+	if strings.Contains(posts[0], "jane@example.com") {
+		t.Errorf("Teams card shows the respondent email: %s", posts[0])
+	}
+
+	if posts := other.posts(); len(posts) != 0 {
+		t.Errorf("disabled and unsubscribed channels got %d messages", len(posts))
+	}
+}
+
+func TestFailingChannelDoesNotAffectOthers(t *testing.T) {
+	down, gone, up := newWebhook(t, http.StatusServiceUnavailable), newWebhook(t, http.StatusNotFound), newWebhook(t, http.StatusOK)
+	forms := channelForm(
+		responseChannel("down", ChannelTypeSlack, down.URL),
+		responseChannel("gone", ChannelTypeTeams, gone.URL),
+		responseChannel("up", ChannelTypeSlack, up.URL),
+	)
+	forms["form-1"].Notifications = Settings{NotifyOwnerOnResponse: true, OwnerEmail: "owner@example.com"}
+
+	sender := &recordingSender{}
+	publisher := &recordingPublisher{}
+	n := newTestNotifier(t, testConfig(), forms, sender, publisher)
+	if err := n.HandleBatch(context.Background(), []*kafka.Message{responseMessage("e1", "")}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := len(down.posts()); got != 3 {
+		t.Errorf("posted %d times to the unavailable webhook, want 1 plus 2 retries", got)
+	}
+	if got := len(gone.posts()); got != 1 {
+		t.Errorf("posted %d times to the rejecting webhook, want no retries", got)
+	}
+	if got := len(up.posts()); got != 1 || len(sender.sent) != 1 {
+		t.Errorf("posted %d messages to the working webhook and sent %d emails, want 1 each", got, len(sender.sent))
+	}
+
+	if len(publisher.messages) != 2 {
+		t.Fatalf("dead-lettered %d events, want one per failing channel", len(publisher.messages))
+	}
+	for i, channelID := range []string{"down", "gone"} {
+		dead := publisher.messages[i]
+		if dead.ID != "e1:channel:"+channelID || dead.Headers[KindHeader] != KindChannel || dead.Headers[ChannelHeader] != channelID {
+			t.Errorf("dead letter %d = %s %v, want the event for channel %s", i, dead.ID, dead.Headers, channelID)
+		}
+		if strings.Contains(dead.Headers[ErrorHeader], "127.0.0.1") {
+			t.Errorf("dead letter error shows the webhook URL: %s", dead.Headers[ErrorHeader])
+		}
+	}
+}
+
+func TestChannelQuietHours(t *testing.T) {
+	berlin := Channel{QuietHoursStart: "22:00", QuietHoursEnd: "07:00", Timezone: "Europe/Berlin"}
+	lunch := Channel{QuietHoursStart: "12:00", QuietHoursEnd: "13:30", Timezone: "UTC"}
+
+	tests := []struct {
+		name    string
+		channel Channel
+		at      time.Time
+		want    bool
+	}{
+		{"before midnight in the channel time zone", berlin, time.Date(2024, 3, 1, 21, 30, 0, 0, time.UTC), true},
+		{"after midnight", berlin, time.Date(2024, 3, 1, 3, 0, 0, 0, time.UTC), true},
+		{"end is not quiet", berlin, time.Date(2024, 3, 1, 6, 0, 0, 0, time.UTC), false},
+		{"daytime", berlin, time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), false},
+		{"within a daytime window", lunch, time.Date(2024, 3, 1, 13, 29, 0, 0, time.UTC), true},
+		{"past a daytime window", lunch, time.Date(2024, 3, 1, 13, 30, 0, 0, time.UTC), false},
+		{"no quiet hours", Channel{Timezone: "UTC"}, time.Date(2024, 3, 1, 3, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		if got := tt.channel.quiet(tt.at); got != tt.want {
+			t.Errorf("%s: quiet = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestChannelTestIgnoresQuietHoursAndEnabled(t *testing.T) {
+	hook := newWebhook(t, http.StatusOK)
+	channel := responseChannel("slack-1", ChannelTypeSlack, hook.URL)
+	channel.Enabled = false
+	channel.QuietHoursStart, channel.QuietHoursEnd = "00:00", "23:59"
+
+	n := newTestNotifier(t, testConfig(), channelForm(channel), &recordingSender{}, &recordingPublisher{})
+	n.now = func() time.Time { return time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC) }
+	batch := []*kafka.Message{
+		{ID: "e1", EventType: ChannelTestEventType, Data: map[string]interface{}{"form_id": "form-1", "channel_id": "slack-1"}},
+		{ID: "e2", EventType: ChannelTestEventType, Data: map[string]interface{}{"form_id": "form-1", "channel_id": "unknown"}},
+		responseMessage("e3", ""),
+	}
+	if err := n.HandleBatch(context.Background(), batch); err != nil {
+		t.Fatal(err)
+	}
+
+	posts := hook.posts()
+	if len(posts) != 1 || !strings.Contains(posts[0], "Test message for Feedback") {
+		t.Errorf("posts = %v, want the test message alone", posts)
+	}
+
+	// Enabled, the channel stays silent in its quiet hours
+	channel.Enabled = true
+	n = newTestNotifier(t, testConfig(), channelForm(channel), &recordingSender{}, &recordingPublisher{})
+	n.now = func() time.Time { return time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC) }
+	if err := n.HandleBatch(context.Background(), []*kafka.Message{responseMessage("e4", "")}); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(hook.posts()); got != 1 {
+		t.Errorf("posted %d messages in the quiet hours, want none", got-1)
+	}
+}
+
+func TestSlackEscapesAnswers(t *testing.T) {
+	hook := newWebhook(t, http.StatusOK)
+	err := NewSlackChannel(hook.URL, http.DefaultClient).Send(context.Background(), nil, ChannelMessage{
+		Title: "New response",
+		Text:  "Text",
+		Facts: []Fact{{Name: "q1", Value: "<!channel> <https://evil.example|click>"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if posts := hook.posts(); len(posts) != 1 || strings.Contains(posts[0], "<!channel>") || !strings.Contains(posts[0], `\u0026lt;!channel\u0026gt;`) {
+		t.Errorf("Slack payload = %v, want the answer escaped", posts)
+	}
+}
@@ -0,0 +1,186 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/projections"
+)
+
+// ErrFormNotFound is returned for forms the form service does not know
+var ErrFormNotFound = errors.New("form not found")
+
+// maxReceiptBytes bounds the receipts attached to emails
+const maxReceiptBytes = 5 << 20
+
+// Settings are the notification settings of a form, as stored by the form
+// service
+type Settings struct {
+	NotifyOwnerOnResponse bool `json:"notify_owner_on_response"`
+	SendRespondentCopy    bool `json:"send_respondent_copy"`
+	// AttachReceipt attaches the PDF receipt of the response to the copy
+	// sent to the respondent
+	AttachReceipt bool   `json:"attach_receipt"`
+	OwnerEmail    string `json:"owner_email,omitempty"`
+	ReplyTo       string `json:"reply_to,omitempty"`
+}
+
+// Target is a form, its notification settings and its chat channels
+type Target struct {
+	FormID        string    `json:"form_id"`
+	OwnerID       string    `json:"owner_id"`
+	Title         string    `json:"title"`
+	Notifications Settings  `json:"notifications"`
+	Channels      []Channel `json:"channels,omitempty"`
+}
+
+// Channel is a Slack or Teams channel of a form, with its webhook URL
+type Channel struct {
+	ID         string   `json:"id"`
+	Type       string   `json:"type"`
+	Name       string   `json:"name"`
+	WebhookURL string   `json:"webhook_url"`
+	EventTypes []string `json:"event_types"`
+	// QuietHoursStart and QuietHoursEnd bound, as HH:MM in Timezone, the
+	// time of day nothing is posted; the end may be past midnight
+	QuietHoursStart string `json:"quiet_hours_start,omitempty"`
+	QuietHoursEnd   string `json:"quiet_hours_end,omitempty"`
+	Timezone        string `json:"timezone"`
+	// IncludeAnswerSummary posts the answers of responses, which are
+	// redacted otherwise
+	IncludeAnswerSummary bool `json:"include_answer_summary"`
+	Enabled              bool `json:"enabled"`
+}
+
+// Forms looks up the notification settings of forms
+type Forms interface {
+	Target(ctx context.Context, formID string) (*Target, error)
+}
+
+// Receipts renders the PDF receipts of responses. Forms implementing it
+// attach receipts to respondent copies when the settings ask for them.
+type Receipts interface {
+	Receipt(ctx context.Context, event *projections.ResponseEvent) (*Attachment, error)
+}
+
+// FormClient reads notification settings from the internal API of the form
+// service, caching them for a TTL so bursts of responses to a form cost a
+// single lookup
+type FormClient struct {
+	baseURL string
+	client  *http.Client
+	ttl     time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedTarget
+}
+
+type cachedTarget struct {
+	target  *Target
+	expires time.Time
+}
+
+// NewFormClient creates a client of the form service at baseURL
+func NewFormClient(baseURL string, ttl time.Duration) *FormClient {
+	return &FormClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: 5 * time.Second},
+		ttl:     ttl,
+		cache:   make(map[string]cachedTarget),
+	}
+}
+
+// Target returns the form and its notification settings
+func (c *FormClient) Target(ctx context.Context, formID string) (*Target, error) {
+	c.mu.Lock()
+	cached, ok := c.cache[formID]
+	c.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.target, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		c.baseURL+"/internal/forms/"+url.PathEscape(formID)+"/notifications", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification settings: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrFormNotFound, formID)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("form service answered %d for the notification settings of %s", resp.StatusCode, formID)
+	}
+
+	var target Target
+	if err := json.NewDecoder(resp.Body).Decode(&target); err != nil {
+		return nil, fmt.Errorf("invalid notification settings: %w", err)
+	}
+
+	if c.ttl > 0 {
+		c.mu.Lock()
+		now := time.Now()
+		for id, entry := range c.cache {
+			if now.After(entry.expires) {
+				delete(c.cache, id)
+			}
+		}
+		c.cache[formID] = cachedTarget{target: &target, expires: now.Add(c.ttl)}
+		c.mu.Unlock()
+	}
+	return &target, nil
+}
+
+// Receipt renders the PDF receipt of the response of event through the
+// internal API of the form service
+func (c *FormClient) Receipt(ctx context.Context, event *projections.ResponseEvent) (*Attachment, error) {
+	body, err := json.Marshal(map[string]any{
+		"response_id":  event.ResponseID,
+		"revision":     event.Revision,
+		"submitted_at": event.SubmittedAt,
+		"answers":      event.Answers,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		c.baseURL+"/internal/forms/"+url.PathEscape(event.FormID)+"/responses/receipt", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get receipt: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrFormNotFound, event.FormID)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("form service answered %d for the receipt of %s", resp.StatusCode, event.ResponseID)
+	}
+	pdf, err := io.ReadAll(io.LimitReader(resp.Body, maxReceiptBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read receipt: %w", err)
+	}
+	if len(pdf) > maxReceiptBytes {
+		return nil, fmt.Errorf("receipt of %s is larger than %d bytes", event.ResponseID, maxReceiptBytes)
+	}
+	return &Attachment{Name: "receipt-" + event.ResponseID + ".pdf", ContentType: "application/pdf", Data: pdf}, nil
+}
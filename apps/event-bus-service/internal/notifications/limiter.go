@@ -0,0 +1,107 @@
+package notifications
+
+import (
+	"sync"
+	"time"
+)
+
+// limiter bounds the emails sent per form in fixed windows. Owner
+// notifications over the limit are held and sent as a single digest once the
+// window ends, so a burst of responses costs the owner one more email.
+type limiter struct {
+	limit  int
+	window time.Duration
+	now    func() time.Time
+
+	mu    sync.Mutex
+	forms map[string]*formWindow
+}
+
+type formWindow struct {
+	start time.Time
+	sent  int
+	// held is the number of owner notifications held back by the limit
+	held int
+}
+
+func newLimiter(limit int, window time.Duration) *limiter {
+	return &limiter{
+		limit:  limit,
+		window: window,
+		now:    time.Now,
+		forms:  make(map[string]*formWindow),
+	}
+}
+
+// current returns the window of the form, starting a new one when the last
+// has ended. The caller holds l.mu.
+func (l *limiter) current(formID string) *formWindow {
+	now := l.now()
+	w, ok := l.forms[formID]
+	if !ok {
+		w = &formWindow{start: now}
+		l.forms[formID] = w
+	} else if now.Sub(w.start) >= l.window {
+		w.start, w.sent = now, 0
+	}
+	return w
+}
+
+// allow takes a slot for an email about the form, reporting false when the
+// limit of the window is reached
+func (l *limiter) allow(formID string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	w := l.current(formID)
+	if w.sent >= l.limit {
+		return false
+	}
+	w.sent++
+	return true
+}
+
+// hold records n owner notifications held back for the next digest
+func (l *limiter) hold(formID string, n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.current(formID).held += n
+}
+
+// release returns and clears the held owner notifications of the form,
+// which the owner email being sent stands for
+func (l *limiter) release(formID string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	w, ok := l.forms[formID]
+	if !ok {
+		return 0
+	}
+	held := w.held
+	w.held = 0
+	return held
+}
+
+// due returns the held owner notifications of the forms whose window has
+// ended, taking a slot of the new window for their digest. Forms with
+// nothing held and an ended window are forgotten.
+func (l *limiter) due() map[string]int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	digests := make(map[string]int)
+	for formID, w := range l.forms {
+		if now.Sub(w.start) < l.window {
+			continue
+		}
+		if w.held == 0 {
+			delete(l.forms, formID)
+			continue
+		}
+		digests[formID] = w.held
+		w.start, w.sent, w.held = now, 1, 0
+	}
+	return digests
+}
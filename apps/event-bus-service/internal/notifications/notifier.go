@@ -0,0 +1,793 @@
+// Package notifications emails form owners when their forms are published,
+// throttled and released, and when responses arrive, respondents a copy of
+// their answers, and scheduled reports to their recipients. The same form
+// events are posted to the Slack and Teams channels of forms. The notifier
+// consumes events off the bus, reads the notification settings of each form
+// from the form service, and sends emails through a Sender and chat cards
+// through a NotificationChannel. Delivery is at least once: a batch cut
+// short by a crash is redelivered.
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/projections"
+)
+
+// Event types published by the form service
+const (
+	FormPublishedEventType    = "form.published"
+	NotificationTestEventType = "form.notification.test"
+	ReportReadyEventType      = "form.report.ready"
+	// The anomaly detector throttled a form whose submissions surged, and
+	// released it once they normalized
+	ThrottleEngagedEventType  = "form.throttle.engaged"
+	ThrottleReleasedEventType = "form.throttle.released"
+)
+
+// Header of dead-lettered events naming the email or the channel that failed
+const (
+	KindHeader    = "notification_kind"
+	ErrorHeader   = "notification_error"
+	ChannelHeader = "notification_channel"
+)
+
+// KindChannel is the kind of the dead-lettered events of chat channels
+const KindChannel = "channel"
+
+const (
+	// maxSummaryAnswers bounds the answers listed in an email
+	maxSummaryAnswers = 20
+	// maxAnswerLength bounds the characters of an answer in an email
+	maxAnswerLength = 200
+	// maxDigestInterval is the longest a digest waits past its window
+	maxDigestInterval = time.Minute
+)
+
+// errInvalidEvent marks events that can never be notified
+var errInvalidEvent = errors.New("invalid notification event")
+
+// Publisher publishes the dead-lettered events
+type Publisher interface {
+	PublishMessage(ctx context.Context, message *kafka.Message) error
+}
+
+// ResponseCounter counts the responses to a form since a time, for the
+// responses of the day shown on chat cards
+type ResponseCounter interface {
+	CountResponses(ctx context.Context, formID string, since time.Time) (int, error)
+}
+
+// Notifier sends the emails of form events. It implements
+// kafka.BatchConsumerHandler.
+type Notifier struct {
+	forms     Forms
+	sender    Sender
+	templates *Templates
+	publisher Publisher
+	limiter   *limiter
+	metrics   *Metrics
+	logger    *zap.Logger
+
+	// channelClient posts to webhooks, at most limiter.limit times per
+	// channel and window of channelLimiter
+	channelClient  *http.Client
+	channelLimiter *limiter
+	counter        ResponseCounter
+	now            func() time.Time
+
+	topics          []string
+	groupID         string
+	appURL          string
+	retryAttempts   int
+	retryBackoff    time.Duration
+	deadLetterTopic string
+}
+
+// New creates a notifier reading settings from forms and sending through
+// sender. Events whose emails or chat messages fail every retry are
+// published to the dead letter topic by publisher. Chat cards show the
+// responses of the day counted by counter, or none when it is nil.
+func New(cfg config.NotificationsConfig, forms Forms, sender Sender, publisher Publisher, counter ResponseCounter, metrics *Metrics, logger *zap.Logger) (*Notifier, error) {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	templates, err := NewTemplates()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Notifier{
+		forms:           forms,
+		sender:          sender,
+		templates:       templates,
+		publisher:       publisher,
+		limiter:         newLimiter(cfg.RateLimit, cfg.RateWindow),
+		metrics:         metrics,
+		logger:          logger,
+		channelClient:   newChannelClient(cfg.ChannelTimeout),
+		channelLimiter:  newLimiter(cfg.RateLimit, cfg.RateWindow),
+		counter:         counter,
+		now:             time.Now,
+		topics:          cfg.Topics,
+		groupID:         cfg.GroupID,
+		appURL:          strings.TrimSuffix(cfg.AppURL, "/"),
+		retryAttempts:   cfg.RetryAttempts,
+		retryBackoff:    cfg.RetryBackoff,
+		deadLetterTopic: cfg.DeadLetterTopic,
+	}, nil
+}
+
+// GetTopics returns the topics the notifier consumes
+func (n *Notifier) GetTopics() []string {
+	return n.topics
+}
+
+// GetGroupID returns the consumer group the notifier commits offsets in
+func (n *Notifier) GetGroupID() string {
+	return n.groupID
+}
+
+// HandleBatch sends the emails of a batch of events. Invalid events and
+// events of unknown forms are dropped; events whose emails still fail after
+// the retries are published to the dead letter topic. The batch only fails,
+// to be redelivered, when the dead letter topic can't be written.
+func (n *Notifier) HandleBatch(ctx context.Context, messages []*kafka.Message) error {
+	for _, message := range messages {
+		var err error
+		switch message.EventType {
+		case projections.ResponseCreatedEventType:
+			err = n.handleResponse(ctx, message)
+		case FormPublishedEventType:
+			err = n.handleFormEvent(ctx, message, KindFormPublished)
+		case NotificationTestEventType:
+			err = n.handleFormEvent(ctx, message, KindTest)
+		case ChannelTestEventType:
+			err = n.handleChannelTest(ctx, message)
+		case ReportReadyEventType:
+			err = n.handleReport(ctx, message)
+		case ThrottleEngagedEventType:
+			err = n.handleThrottle(ctx, message, KindThrottleEngaged)
+		case ThrottleReleasedEventType:
+			err = n.handleThrottle(ctx, message, KindThrottleReleased)
+		default:
+			n.metrics.Events.WithLabelValues("skipped").Inc()
+			continue
+		}
+
+		switch {
+		case err == nil:
+			n.metrics.Events.WithLabelValues("handled").Inc()
+		case errors.Is(err, errInvalidEvent), errors.Is(err, ErrFormNotFound):
+			n.logger.Warn("Dropping notification event",
+				zap.String("event_id", message.ID),
+				zap.String("event_type", message.EventType),
+				zap.Error(err))
+			n.metrics.Events.WithLabelValues("invalid").Inc()
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// handleResponse notifies the owner and the channels of the form of a new
+// response and sends the respondent a copy of their answers, as the form
+// settings ask
+func (n *Notifier) handleResponse(ctx context.Context, message *kafka.Message) error {
+	event, err := projections.ParseResponseEvent(message)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errInvalidEvent, err)
+	}
+	// Test submissions notify no one
+	if event.Test {
+		return nil
+	}
+	target, err := n.target(ctx, message, event.FormID)
+	if target == nil {
+		return err
+	}
+	settings := target.Notifications
+
+	summary, more := summarize(event.Answers)
+	data := TemplateData{
+		FormID:      target.FormID,
+		FormTitle:   target.Title,
+		Link:        n.link("forms", target.FormID, "responses", event.ResponseID),
+		ResponseID:  event.ResponseID,
+		SubmittedAt: event.SubmittedAt,
+		Summary:     summary,
+		MoreAnswers: more,
+	}
+	if err := n.notifyChannels(ctx, message, target, KindOwnerResponse, data); err != nil {
+		return err
+	}
+
+	if settings.NotifyOwnerOnResponse && settings.OwnerEmail != "" {
+		if n.limiter.allow(target.FormID) {
+			data.Pending = n.limiter.release(target.FormID)
+			email := Email{To: settings.OwnerEmail, ReplyTo: event.Respondent.Email}
+			if err := n.deliver(ctx, message, KindOwnerResponse, email, data); err != nil {
+				n.limiter.hold(target.FormID, data.Pending)
+				return err
+			}
+		} else {
+			n.limiter.hold(target.FormID, 1)
+			n.metrics.Emails.WithLabelValues(KindOwnerResponse, "held").Inc()
+		}
+	}
+
+	if settings.SendRespondentCopy && event.Respondent.Email != "" {
+		if !n.limiter.allow(target.FormID) {
+			n.metrics.Emails.WithLabelValues(KindRespondentCopy, "rate_limited").Inc()
+			return nil
+		}
+		data.Link = n.link("forms", target.FormID)
+		data.Pending = 0
+		email := Email{To: event.Respondent.Email, ReplyTo: settings.ReplyTo}
+		if settings.AttachReceipt {
+			email.Attachments = n.receipt(ctx, event)
+		}
+		if err := n.deliver(ctx, message, KindRespondentCopy, email, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// receipt returns the receipt of the response of event as attachments. A
+// receipt that can't be rendered is left out rather than holding the copy
+// back.
+func (n *Notifier) receipt(ctx context.Context, event *projections.ResponseEvent) []Attachment {
+	receipts, ok := n.forms.(Receipts)
+	if !ok {
+		return nil
+	}
+	var attachment *Attachment
+	err := n.retry(ctx, func() error {
+		var err error
+		attachment, err = receipts.Receipt(ctx, event)
+		return err
+	})
+	if err != nil {
+		n.metrics.Emails.WithLabelValues(KindRespondentCopy, "receipt_failed").Inc()
+		n.logger.Warn("Sending respondent copy without its receipt",
+			zap.String("form_id", event.FormID),
+			zap.String("response_id", event.ResponseID),
+			zap.Error(err))
+		return nil
+	}
+	return []Attachment{*attachment}
+}
+
+// handleFormEvent emails the owner about the form of the event, and posts it
+// to the channels of the form
+func (n *Notifier) handleFormEvent(ctx context.Context, message *kafka.Message, kind string) error {
+	var event struct {
+		FormID string `json:"form_id"`
+	}
+	if err := decode(message, &event); err != nil {
+		return err
+	}
+	if event.FormID == "" {
+		return fmt.Errorf("%w: form_id is required", errInvalidEvent)
+	}
+
+	target, err := n.target(ctx, message, event.FormID)
+	if target == nil {
+		return err
+	}
+
+	data := TemplateData{
+		FormID:    target.FormID,
+		FormTitle: target.Title,
+		Link:      n.link("forms", target.FormID),
+	}
+	if err := n.notifyChannels(ctx, message, target, kind, data); err != nil {
+		return err
+	}
+	if target.Notifications.OwnerEmail == "" {
+		n.metrics.Emails.WithLabelValues(kind, "no_recipient").Inc()
+		return nil
+	}
+	if !n.limiter.allow(target.FormID) {
+		n.metrics.Emails.WithLabelValues(kind, "rate_limited").Inc()
+		return nil
+	}
+	return n.deliver(ctx, message, kind, Email{To: target.Notifications.OwnerEmail}, data)
+}
+
+// handleThrottle tells the owner that their form was throttled or released.
+// These emails are not rate limited: the surge that throttled the form may
+// have used up the limit with owner notifications.
+func (n *Notifier) handleThrottle(ctx context.Context, message *kafka.Message, kind string) error {
+	var event struct {
+		FormID            string  `json:"form_id"`
+		RatePerMinute     float64 `json:"rate_per_minute"`
+		BaselinePerMinute float64 `json:"baseline_per_minute"`
+	}
+	if err := decode(message, &event); err != nil {
+		return err
+	}
+	if event.FormID == "" {
+		return fmt.Errorf("%w: form_id is required", errInvalidEvent)
+	}
+
+	target, err := n.target(ctx, message, event.FormID)
+	if target == nil {
+		return err
+	}
+
+	data := TemplateData{
+		FormID:            target.FormID,
+		FormTitle:         target.Title,
+		Link:              n.link("forms", target.FormID),
+		RatePerMinute:     event.RatePerMinute,
+		BaselinePerMinute: event.BaselinePerMinute,
+	}
+	if err := n.notifyChannels(ctx, message, target, kind, data); err != nil {
+		return err
+	}
+	if target.Notifications.OwnerEmail == "" {
+		n.metrics.Emails.WithLabelValues(kind, "no_recipient").Inc()
+		return nil
+	}
+	return n.deliver(ctx, message, kind, Email{To: target.Notifications.OwnerEmail}, data)
+}
+
+// handleReport emails a scheduled report to its recipients. The form service
+// lists the recipients in the event, so the form is not looked up, and
+// reports are not rate limited as they are at most daily.
+func (n *Notifier) handleReport(ctx context.Context, message *kafka.Message) error {
+	var event struct {
+		FormID         string    `json:"form_id"`
+		Title          string    `json:"title"`
+		Frequency      string    `json:"frequency"`
+		PeriodStart    time.Time `json:"period_start"`
+		PeriodEnd      time.Time `json:"period_end"`
+		Timezone       string    `json:"timezone"`
+		Recipients     []string  `json:"recipients"`
+		TotalResponses int       `json:"total_responses"`
+		CompletionRate float64   `json:"completion_rate"`
+		DownloadURL    string    `json:"download_url"`
+	}
+	if err := decode(message, &event); err != nil {
+		return err
+	}
+	if event.FormID == "" || event.DownloadURL == "" {
+		return fmt.Errorf("%w: form_id and download_url are required", errInvalidEvent)
+	}
+
+	loc, err := time.LoadLocation(event.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	data := TemplateData{
+		FormID:         event.FormID,
+		FormTitle:      event.Title,
+		Link:           event.DownloadURL,
+		Frequency:      event.Frequency,
+		PeriodStart:    event.PeriodStart.In(loc),
+		PeriodEnd:      event.PeriodEnd.In(loc),
+		Responses:      event.TotalResponses,
+		CompletionRate: event.CompletionRate,
+	}
+	for _, recipient := range event.Recipients {
+		if err := n.deliver(ctx, message, KindReport, Email{To: recipient}, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handleChannelTest posts a test card to a channel of a form, whether it is
+// enabled or not and in its quiet hours too, so owners can check its webhook
+func (n *Notifier) handleChannelTest(ctx context.Context, message *kafka.Message) error {
+	var event struct {
+		FormID    string `json:"form_id"`
+		ChannelID string `json:"channel_id"`
+	}
+	if err := decode(message, &event); err != nil {
+		return err
+	}
+	if event.FormID == "" || event.ChannelID == "" {
+		return fmt.Errorf("%w: form_id and channel_id are required", errInvalidEvent)
+	}
+
+	target, err := n.target(ctx, message, event.FormID)
+	if target == nil {
+		return err
+	}
+	for _, channel := range target.Channels {
+		if channel.ID == event.ChannelID {
+			return n.post(ctx, message, channel, KindTest, TemplateData{
+				FormID:    target.FormID,
+				FormTitle: target.Title,
+				Link:      n.link("forms", target.FormID),
+			})
+		}
+	}
+	return fmt.Errorf("%w: form %s has no channel %s", errInvalidEvent, event.FormID, event.ChannelID)
+}
+
+// notifyChannels posts the event to the enabled channels of the form that
+// subscribe to it, unless in their quiet hours. Answers are left out of the
+// cards of channels not including them. Channels are retried and
+// dead-lettered one by one, so a failing webhook does not hold the others
+// back; only a failed dead letter publication is returned.
+func (n *Notifier) notifyChannels(ctx context.Context, message *kafka.Message, target *Target, kind string, data TemplateData) error {
+	for _, channel := range target.Channels {
+		if !channel.Enabled || !channel.subscribes(message.EventType) {
+			continue
+		}
+		if channel.quiet(n.now()) {
+			n.metrics.Channels.WithLabelValues(channel.Type, kind, "quiet_hours").Inc()
+			continue
+		}
+		if !n.channelLimiter.allow(channel.ID) {
+			n.metrics.Channels.WithLabelValues(channel.Type, kind, "rate_limited").Inc()
+			continue
+		}
+		if err := n.post(ctx, message, channel, kind, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// post renders the card of kind for a channel and posts it with retries,
+// dead-lettering the event for the channel when every attempt fails
+func (n *Notifier) post(ctx context.Context, message *kafka.Message, channel Channel, kind string, data TemplateData) error {
+	newChannel, ok := channelFactories[channel.Type]
+	if !ok {
+		return n.deadLetterChannel(ctx, message, channel, kind, fmt.Errorf("unknown channel type %q", channel.Type))
+	}
+	if !channel.IncludeAnswerSummary {
+		data.Summary, data.MoreAnswers = nil, 0
+	}
+	card, err := n.templates.RenderCard(kind, data)
+	if err != nil {
+		return n.deadLetterChannel(ctx, message, channel, kind, err)
+	}
+	if today, ok := n.responsesToday(ctx, data.FormID, channel.location()); ok {
+		card.Facts = append([]Fact{{Name: "Responses today", Value: fmt.Sprintf("%d", today)}}, card.Facts...)
+	}
+
+	sender := newChannel(channel.WebhookURL, n.channelClient)
+	err = n.retry(ctx, func() error {
+		return sender.Send(ctx, message, card)
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		n.metrics.Channels.WithLabelValues(channel.Type, kind, "failed").Inc()
+		return n.deadLetterChannel(ctx, message, channel, kind, err)
+	}
+
+	n.metrics.Channels.WithLabelValues(channel.Type, kind, "sent").Inc()
+	n.logger.Debug("Posted notification",
+		zap.String("kind", kind),
+		zap.String("channel_id", channel.ID),
+		zap.String("form_id", data.FormID),
+		zap.String("event_id", message.ID))
+	return nil
+}
+
+// responsesToday counts the responses to the form since midnight in loc,
+// reporting false when they can't be counted
+func (n *Notifier) responsesToday(ctx context.Context, formID string, loc *time.Location) (int, bool) {
+	if n.counter == nil {
+		return 0, false
+	}
+	year, month, day := n.now().In(loc).Date()
+	count, err := n.counter.CountResponses(ctx, formID, time.Date(year, month, day, 0, 0, 0, 0, loc))
+	if err != nil {
+		n.logger.Warn("Failed to count the responses of the day",
+			zap.String("form_id", formID),
+			zap.Error(err))
+		return 0, false
+	}
+	return count, true
+}
+
+// target looks the form up, with retries. When the lookup keeps failing the
+// event is dead-lettered and a nil target is returned with the error of the
+// dead letter publication, if any.
+func (n *Notifier) target(ctx context.Context, message *kafka.Message, formID string) (*Target, error) {
+	var target *Target
+	err := n.retry(ctx, func() error {
+		var err error
+		target, err = n.forms.Target(ctx, formID)
+		return err
+	})
+	if err == nil {
+		return target, nil
+	}
+	if errors.Is(err, ErrFormNotFound) || ctx.Err() != nil {
+		return nil, err
+	}
+	return nil, n.deadLetter(ctx, message, "settings", err)
+}
+
+// deliver renders and sends an email with retries, dead-lettering the event
+// when every attempt fails. Only a failed dead letter publication is returned.
+func (n *Notifier) deliver(ctx context.Context, message *kafka.Message, kind string, email Email, data TemplateData) error {
+	subject, body, err := n.templates.Render(kind, data)
+	if err != nil {
+		return n.deadLetter(ctx, message, kind, err)
+	}
+	email.Subject, email.Body = subject, body
+
+	err = n.retry(ctx, func() error {
+		return n.sender.Send(ctx, email)
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		n.metrics.Emails.WithLabelValues(kind, "failed").Inc()
+		return n.deadLetter(ctx, message, kind, err)
+	}
+
+	n.metrics.Emails.WithLabelValues(kind, "sent").Inc()
+	n.logger.Debug("Sent notification",
+		zap.String("kind", kind),
+		zap.String("form_id", data.FormID),
+		zap.String("event_id", message.ID))
+	return nil
+}
+
+// retry calls fn until it succeeds, up to 1+retryAttempts times, doubling
+// the backoff between attempts. Unknown forms and webhooks rejecting the
+// message are not retried.
+func (n *Notifier) retry(ctx context.Context, fn func() error) error {
+	backoff := n.retryBackoff
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = fn(); err == nil || errors.Is(err, ErrFormNotFound) || errors.Is(err, errWebhookRejected) || attempt >= n.retryAttempts {
+			return err
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+// deadLetter publishes the event to the dead letter topic, with the email
+// that failed and the error in its headers
+func (n *Notifier) deadLetter(ctx context.Context, message *kafka.Message, kind string, cause error) error {
+	n.logger.Error("Dead-lettering notification event",
+		zap.String("event_id", message.ID),
+		zap.String("kind", kind),
+		zap.Error(cause))
+
+	if err := n.publishDeadLetter(ctx, message, kind, map[string]string{KindHeader: kind, ErrorHeader: cause.Error()}); err != nil {
+		return err
+	}
+	n.metrics.Emails.WithLabelValues(kind, "dead_lettered").Inc()
+	return nil
+}
+
+// deadLetterChannel publishes the event to the dead letter topic for a
+// channel, with the channel that failed and the error in its headers
+func (n *Notifier) deadLetterChannel(ctx context.Context, message *kafka.Message, channel Channel, kind string, cause error) error {
+	n.logger.Error("Dead-lettering notification event",
+		zap.String("event_id", message.ID),
+		zap.String("kind", kind),
+		zap.String("channel_id", channel.ID),
+		zap.Error(cause))
+
+	err := n.publishDeadLetter(ctx, message, KindChannel+":"+channel.ID, map[string]string{
+		KindHeader:    KindChannel,
+		ChannelHeader: channel.ID,
+		ErrorHeader:   cause.Error(),
+	})
+	if err != nil {
+		return err
+	}
+	n.metrics.Channels.WithLabelValues(channel.Type, kind, "dead_lettered").Inc()
+	return nil
+}
+
+// publishDeadLetter publishes a copy of the event with extra headers to the
+// dead letter topic, its ID suffixed so the copies of an event are distinct
+func (n *Notifier) publishDeadLetter(ctx context.Context, message *kafka.Message, suffix string, extra map[string]string) error {
+	headers := make(map[string]string, len(message.Headers)+len(extra))
+	for key, value := range message.Headers {
+		headers[key] = value
+	}
+	for key, value := range extra {
+		headers[key] = value
+	}
+
+	metadata := message.Metadata
+	metadata.OriginalTopic = message.Topic
+	metadata.RetryCount = n.retryAttempts
+
+	err := n.publisher.PublishMessage(ctx, &kafka.Message{
+		ID:            message.ID + ":" + suffix,
+		CorrelationID: message.CorrelationID,
+		EventType:     message.EventType,
+		Source:        message.Source,
+		Subject:       message.Subject,
+		Data:          message.Data,
+		Headers:       headers,
+		Metadata:      metadata,
+		Topic:         n.deadLetterTopic,
+		Key:           message.Key,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to dead-letter notification event %s: %w", message.ID, err)
+	}
+	return nil
+}
+
+// RunDigests sends the digests of held owner notifications as the rate limit
+// windows end, until ctx is done
+func (n *Notifier) RunDigests(ctx context.Context) {
+	interval := n.limiter.window
+	if interval > maxDigestInterval {
+		interval = maxDigestInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n.sendDigests(ctx)
+		}
+	}
+}
+
+// sendDigests sends a digest to the owner of each form with held
+// notifications and an ended window. Digests that can't be sent are held
+// for the next window.
+func (n *Notifier) sendDigests(ctx context.Context) {
+	for formID, held := range n.limiter.due() {
+		target, err := n.forms.Target(ctx, formID)
+		if errors.Is(err, ErrFormNotFound) {
+			continue
+		}
+		if err == nil && target.Notifications.OwnerEmail == "" {
+			continue
+		}
+		if err == nil {
+			data := TemplateData{
+				FormID:    target.FormID,
+				FormTitle: target.Title,
+				Link:      n.link("forms", target.FormID, "responses"),
+				Pending:   held,
+			}
+			var subject, body string
+			if subject, body, err = n.templates.Render(KindOwnerDigest, data); err == nil {
+				err = n.retry(ctx, func() error {
+					return n.sender.Send(ctx, Email{To: target.Notifications.OwnerEmail, Subject: subject, Body: body})
+				})
+			}
+		}
+		if err != nil {
+			n.logger.Warn("Failed to send notification digest, holding it for the next window",
+				zap.String("form_id", formID),
+				zap.Int("held", held),
+				zap.Error(err))
+			n.metrics.Emails.WithLabelValues(KindOwnerDigest, "failed").Inc()
+			n.limiter.hold(formID, held)
+			continue
+		}
+		n.metrics.Emails.WithLabelValues(KindOwnerDigest, "sent").Inc()
+	}
+}
+
+// link joins path segments to the app URL
+func (n *Notifier) link(segments ...string) string {
+	return n.appURL + "/" + strings.Join(segments, "/")
+}
+
+// summarize lists the answers by question ID, returning the number of
+// answers left out
+func summarize(answers map[string]json.RawMessage) ([]SummaryLine, int) {
+	questionIDs := make([]string, 0, len(answers))
+	for questionID := range answers {
+		questionIDs = append(questionIDs, questionID)
+	}
+	sort.Strings(questionIDs)
+
+	more := 0
+	if len(questionIDs) > maxSummaryAnswers {
+		more = len(questionIDs) - maxSummaryAnswers
+		questionIDs = questionIDs[:maxSummaryAnswers]
+	}
+	lines := make([]SummaryLine, 0, len(questionIDs))
+	for _, questionID := range questionIDs {
+		lines = append(lines, SummaryLine{Question: questionID, Answer: formatAnswer(answers[questionID])})
+	}
+	return lines, more
+}
+
+// formatAnswer renders an answer on a single line: strings as they are,
+// lists of strings comma-separated and other values as JSON
+func formatAnswer(raw json.RawMessage) string {
+	var text string
+	var list []string
+	switch {
+	case json.Unmarshal(raw, &text) == nil:
+	case json.Unmarshal(raw, &list) == nil:
+		text = strings.Join(list, ", ")
+	default:
+		text = string(raw)
+	}
+
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) > maxAnswerLength {
+		text = string([]rune(text)[:maxAnswerLength]) + "…"
+	}
+	return text
+}
+
+// decode unmarshals the payload of message into v
+func decode(message *kafka.Message, v interface{}) error {
+	var payload []byte
+	switch data := message.Data.(type) {
+	case []byte:
+		payload = data
+	case json.RawMessage:
+		payload = data
+	default:
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return fmt.Errorf("%w: %v", errInvalidEvent, err)
+		}
+		payload = encoded
+	}
+	if err := json.Unmarshal(payload, v); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidEvent, err)
+	}
+	return nil
+}
+
+// Metrics contains the Prometheus metrics of the notifier
+type Metrics struct {
+	Events   *prometheus.CounterVec
+	Emails   *prometheus.CounterVec
+	Channels *prometheus.CounterVec
+}
+
+// NewMetrics creates the notifier metrics and registers them with reg
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		Events: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "eventbus_notification_events_total",
+			Help: "Total number of events consumed by the notifier, by outcome",
+		}, []string{"status"}),
+		Emails: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "eventbus_notification_emails_total",
+			Help: "Total number of notification emails, by kind and outcome",
+		}, []string{"kind", "status"}),
+		Channels: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "eventbus_notification_channel_messages_total",
+			Help: "Total number of messages to Slack and Teams channels, by channel type, kind and outcome",
+		}, []string{"type", "kind", "status"}),
+	}
+	reg.MustRegister(m.Events, m.Emails, m.Channels)
+	return m
+}
@@ -0,0 +1,491 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/projections"
+)
+
+type memoryForms map[string]*Target
+
+func (f memoryForms) Target(_ context.Context, formID string) (*Target, error) {
+	target, ok := f[formID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrFormNotFound, formID)
+	}
+	return target, nil
+}
+
+// recordingSender records the emails sent, failing the first failures sends
+type recordingSender struct {
+	mu       sync.Mutex
+	failures int
+	attempts int
+	sent     []Email
+}
+
+func (s *recordingSender) Send(_ context.Context, email Email) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts++
+	if s.attempts <= s.failures {
+		return errors.New("421 service not available")
+	}
+	s.sent = append(s.sent, email)
+	return nil
+}
+
+type recordingPublisher struct {
+	err      error
+	messages []*kafka.Message
+}
+
+func (p *recordingPublisher) PublishMessage(_ context.Context, message *kafka.Message) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.messages = append(p.messages, message)
+	return nil
+}
+
+func testConfig() config.NotificationsConfig {
+	return config.NotificationsConfig{
+		AppURL:          "https://xform.example.com/",
+		RateLimit:       10,
+		RateWindow:      time.Hour,
+		RetryAttempts:   2,
+		RetryBackoff:    time.Millisecond,
+		DeadLetterTopic: "app.notifications.dlq",
+		ChannelTimeout:  time.Second,
+	}
+}
+
+func newTestNotifier(t *testing.T, cfg config.NotificationsConfig, forms Forms, sender Sender, publisher Publisher) *Notifier {
+	t.Helper()
+	n, err := New(cfg, forms, sender, publisher, nil, NewMetrics(prometheus.NewRegistry()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func feedbackForm(settings Settings) memoryForms {
+	return memoryForms{"form-1": {FormID: "form-1", OwnerID: "owner-1", Title: "Feedback", Notifications: settings}}
+}
+
+func responseMessage(id, email string) *kafka.Message {
+	event := projections.ResponseEvent{
+		ResponseID:  "response-" + id,
+		FormID:      "form-1",
+		AnswersHash: "hash",
+		Answers: map[string]json.RawMessage{
+			"q1": json.RawMessage(`"Great service"`),
+			"q2": json.RawMessage(`["Email","Phone"]`),
+			"q3": json.RawMessage(`5`),
+		},
+		Respondent:  projections.Respondent{Email: email},
+		SubmittedAt: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+	data, _ := json.Marshal(event)
+	return &kafka.Message{
+		ID:        id,
+		EventType: projections.ResponseCreatedEventType,
+		Topic:     "app.form.response.created",
+		Data:      json.RawMessage(data),
+	}
+}
+
+func TestResponseNotifiesOwnerAndRespondent(t *testing.T) {
+	sender := &recordingSender{}
+	n := newTestNotifier(t, testConfig(), feedbackForm(Settings{
+		NotifyOwnerOnResponse: true,
+		SendRespondentCopy:    true,
+		OwnerEmail:            "owner@example.com",
+		ReplyTo:               "support@example.com",
+	}), sender, &recordingPublisher{})
+
+	if err := n.HandleBatch(context.Background(), []*kafka.Message{responseMessage("e1", "jane@example.com")}); err != nil {
+		t.Fatal(err)
+	}
+	if len(sender.sent) != 2 {
+		t.Fatalf("sent %d emails, want the owner notification and the respondent copy", len(sender.sent))
+	}
+
+	owner, copy := sender.sent[0], sender.sent[1]
+	if owner.To != "owner@example.com" || owner.ReplyTo != "jane@example.com" || owner.Subject != "New response to Feedback" {
+		t.Errorf("owner email = %+v", owner)
+	}
+	for _, want := range []string{
+		"- q1: Great service\n",
+		"- q2: Email, Phone\n",
+		"- q3: 5\n",
+		"https://xform.example.com/forms/form-1/responses/response-e1",
+	} {
+		if !strings.Contains(owner.Body, want) {
+			t.Errorf("owner email does not contain %q:\n%s", want, owner.Body)
+		}
+	}
+	if copy.To != "jane@example.com" || copy.ReplyTo != "support@example.com" || copy.Subject != "Your response to Feedback" {
+		t.Errorf("respondent copy = %+v", copy)
+	}
+	if strings.Contains(copy.Body, "/responses/") {
+		t.Errorf("respondent copy links to the response:\n%s", copy.Body)
+	}
+
+	// Respondents without an address get no copy
+	sender.sent = nil
+	if err := n.HandleBatch(context.Background(), []*kafka.Message{responseMessage("e2", "")}); err != nil {
+		t.Fatal(err)
+	}
+	if len(sender.sent) != 1 || sender.sent[0].To != "owner@example.com" {
+		t.Errorf("sent %+v, want the owner notification only", sender.sent)
+	}
+
+	// Test submissions notify no one
+	sender.sent = nil
+	message := responseMessage("e3", "jane@example.com")
+	var event projections.ResponseEvent
+	json.Unmarshal(message.Data.(json.RawMessage), &event)
+	event.Test = true
+	message.Data, _ = json.Marshal(event)
+	if err := n.HandleBatch(context.Background(), []*kafka.Message{message}); err != nil {
+		t.Fatal(err)
+	}
+	if len(sender.sent) != 0 {
+		t.Errorf("sent %+v for a test submission, want none", sender.sent)
+	}
+}
+
+// receiptForms renders receipts of the responses of its forms, failing
+// with err
+type receiptForms struct {
+	memoryForms
+	err      error
+	rendered []string
+}
+
+func (f *receiptForms) Receipt(_ context.Context, event *projections.ResponseEvent) (*Attachment, error) {
+	f.rendered = append(f.rendered, event.ResponseID)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &Attachment{Name: "receipt-" + event.ResponseID + ".pdf", ContentType: "application/pdf", Data: []byte("%PDF-1.3")}, nil
+}
+
+func TestRespondentCopyAttachesReceipt(t *testing.T) {
+	sender := &recordingSender{}
+	forms := &receiptForms{memoryForms: feedbackForm(Settings{
+		NotifyOwnerOnResponse: true,
+		SendRespondentCopy:    true,
+		AttachReceipt:         true,
+		OwnerEmail:            "owner@example.com",
+	})}
+	n := newTestNotifier(t, testConfig(), forms, sender, &recordingPublisher{})
+
+	if err := n.HandleBatch(context.Background(), []*kafka.Message{responseMessage("e1", "jane@example.com")}); err != nil {
+		t.Fatal(err)
+	}
+	if len(sender.sent) != 2 {
+		t.Fatalf("sent %d emails, want the owner notification and the respondent copy", len(sender.sent))
+	}
+	if owner := sender.sent[0]; len(owner.Attachments) != 0 {
+		t.Errorf("owner notification has %d attachments, want none", len(owner.Attachments))
+	}
+	copy := sender.sent[1]
+	if len(copy.Attachments) != 1 || copy.Attachments[0].Name != "receipt-response-e1.pdf" || copy.Attachments[0].ContentType != "application/pdf" {
+		t.Errorf("respondent copy attachments = %+v, want the receipt", copy.Attachments)
+	}
+
+	// A receipt failing every retry is left out of the copy
+	sender.sent, forms.rendered = nil, nil
+	forms.err = errors.New("form service answered 500")
+	if err := n.HandleBatch(context.Background(), []*kafka.Message{responseMessage("e2", "jane@example.com")}); err != nil {
+		t.Fatal(err)
+	}
+	if len(forms.rendered) != 3 {
+		t.Errorf("receipt asked for %d times, want 3 attempts", len(forms.rendered))
+	}
+	if len(sender.sent) != 2 || len(sender.sent[1].Attachments) != 0 {
+		t.Errorf("sent %+v, want the respondent copy without its receipt", sender.sent)
+	}
+}
+
+func TestRateLimitHoldsOwnerNotificationsForADigest(t *testing.T) {
+	cfg := testConfig()
+	cfg.RateLimit = 1
+	sender := &recordingSender{}
+	n := newTestNotifier(t, cfg, feedbackForm(Settings{NotifyOwnerOnResponse: true, OwnerEmail: "owner@example.com"}), sender, &recordingPublisher{})
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	n.limiter.now = func() time.Time { return now }
+
+	batch := []*kafka.Message{responseMessage("e1", ""), responseMessage("e2", ""), responseMessage("e3", "")}
+	if err := n.HandleBatch(context.Background(), batch); err != nil {
+		t.Fatal(err)
+	}
+	if len(sender.sent) != 1 {
+		t.Fatalf("sent %d emails in the window, want 1", len(sender.sent))
+	}
+
+	n.sendDigests(context.Background())
+	if len(sender.sent) != 1 {
+		t.Fatal("a digest was sent before the window ended")
+	}
+
+	now = now.Add(time.Hour)
+	n.sendDigests(context.Background())
+	if len(sender.sent) != 2 {
+		t.Fatalf("sent %d emails after the window, want the digest", len(sender.sent))
+	}
+	if digest := sender.sent[1]; digest.Subject != "2 new responses to Feedback" {
+		t.Errorf("digest subject = %q", digest.Subject)
+	}
+
+	// The digest took the slot of the new window, so the next response is
+	// held, then folded into the owner email of the following window
+	if err := n.HandleBatch(context.Background(), []*kafka.Message{responseMessage("e4", "")}); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(time.Hour)
+	if err := n.HandleBatch(context.Background(), []*kafka.Message{responseMessage("e5", "")}); err != nil {
+		t.Fatal(err)
+	}
+	if len(sender.sent) != 3 || !strings.Contains(sender.sent[2].Body, "1 more responses arrived") {
+		t.Errorf("sent %+v, want the held response folded into the next notification", sender.sent)
+	}
+	n.sendDigests(context.Background())
+	if len(sender.sent) != 3 {
+		t.Error("a folded notification was sent again as a digest")
+	}
+}
+
+func TestFailedEmailsAreRetriedThenDeadLettered(t *testing.T) {
+	forms := feedbackForm(Settings{NotifyOwnerOnResponse: true, OwnerEmail: "owner@example.com"})
+
+	// A send succeeding within the retries is not dead-lettered
+	sender := &recordingSender{failures: 2}
+	publisher := &recordingPublisher{}
+	n := newTestNotifier(t, testConfig(), forms, sender, publisher)
+	if err := n.HandleBatch(context.Background(), []*kafka.Message{responseMessage("e1", "")}); err != nil {
+		t.Fatal(err)
+	}
+	if len(sender.sent) != 1 || len(publisher.messages) != 0 {
+		t.Errorf("sent %d emails and dead-lettered %d events, want the email sent on its third attempt", len(sender.sent), len(publisher.messages))
+	}
+
+	// Past the retries the event goes to the dead letter topic
+	sender = &recordingSender{failures: 100}
+	n = newTestNotifier(t, testConfig(), forms, sender, publisher)
+	if err := n.HandleBatch(context.Background(), []*kafka.Message{responseMessage("e2", "")}); err != nil {
+		t.Fatal(err)
+	}
+	if sender.attempts != 3 {
+		t.Errorf("made %d attempts, want 1 plus 2 retries", sender.attempts)
+	}
+	if len(publisher.messages) != 1 {
+		t.Fatalf("dead-lettered %d events, want 1", len(publisher.messages))
+	}
+	dead := publisher.messages[0]
+	if dead.Topic != "app.notifications.dlq" || dead.Metadata.OriginalTopic != "app.form.response.created" ||
+		dead.Headers[KindHeader] != KindOwnerResponse || !strings.Contains(dead.Headers[ErrorHeader], "421") {
+		t.Errorf("dead letter = %+v", dead)
+	}
+
+	// A dead letter topic that can't be written fails the batch, which is
+	// then redelivered
+	n = newTestNotifier(t, testConfig(), forms, &recordingSender{failures: 100}, &recordingPublisher{err: errors.New("broker down")})
+	if err := n.HandleBatch(context.Background(), []*kafka.Message{responseMessage("e3", "")}); err == nil {
+		t.Error("the batch succeeded without its dead letter")
+	}
+}
+
+func TestFormEventsNotifyTheOwner(t *testing.T) {
+	sender := &recordingSender{}
+	n := newTestNotifier(t, testConfig(), feedbackForm(Settings{OwnerEmail: "owner@example.com"}), sender, &recordingPublisher{})
+
+	batch := []*kafka.Message{
+		{ID: "e1", EventType: FormPublishedEventType, Data: map[string]interface{}{"form_id": "form-1", "title": "Feedback"}},
+		{ID: "e2", EventType: NotificationTestEventType, Data: map[string]interface{}{"form_id": "form-1"}},
+		{ID: "e3", EventType: NotificationTestEventType, Data: map[string]interface{}{"form_id": "unknown"}},
+		{ID: "e4", EventType: NotificationTestEventType, Data: "not an object"},
+		{ID: "e5", EventType: "form.deleted"},
+	}
+	if err := n.HandleBatch(context.Background(), batch); err != nil {
+		t.Fatal(err)
+	}
+	if len(sender.sent) != 2 {
+		t.Fatalf("sent %d emails, want the published and test emails", len(sender.sent))
+	}
+	if got := sender.sent[0].Subject; got != "Feedback is published" {
+		t.Errorf("published subject = %q", got)
+	}
+	if got := sender.sent[1]; got.To != "owner@example.com" || got.Subject != "Test notification for Feedback" {
+		t.Errorf("test email = %+v", got)
+	}
+}
+
+func TestThrottlingNotifiesTheOwnerPastTheRateLimit(t *testing.T) {
+	cfg := testConfig()
+	cfg.RateLimit = 1
+	sender := &recordingSender{}
+	n := newTestNotifier(t, cfg, feedbackForm(Settings{OwnerEmail: "owner@example.com"}), sender, &recordingPublisher{})
+
+	batch := []*kafka.Message{
+		{ID: "e1", EventType: FormPublishedEventType, Data: map[string]interface{}{"form_id": "form-1"}},
+		{ID: "e2", EventType: ThrottleEngagedEventType, Data: map[string]interface{}{"form_id": "form-1", "rate_per_minute": 840, "baseline_per_minute": 2.5}},
+		{ID: "e3", EventType: ThrottleReleasedEventType, Data: map[string]interface{}{"form_id": "form-1", "rate_per_minute": 3, "baseline_per_minute": 2.5}},
+	}
+	if err := n.HandleBatch(context.Background(), batch); err != nil {
+		t.Fatal(err)
+	}
+	if len(sender.sent) != 3 {
+		t.Fatalf("sent %d emails, want the throttle emails past the rate limit", len(sender.sent))
+	}
+	engaged := sender.sent[1]
+	if engaged.Subject != "Unusual submissions to Feedback: CAPTCHA enabled" || !strings.Contains(engaged.Body, "840.0 submissions a minute, against 2.5 usually") {
+		t.Errorf("engaged email = %+v", engaged)
+	}
+	if got := sender.sent[2].Subject; got != "Submissions to Feedback are back to normal" {
+		t.Errorf("released subject = %q", got)
+	}
+}
+
+func TestReportIsEmailedToItsRecipients(t *testing.T) {
+	sender := &recordingSender{}
+	n := newTestNotifier(t, testConfig(), memoryForms{}, sender, &recordingPublisher{})
+
+	batch := []*kafka.Message{
+		{ID: "e1", EventType: ReportReadyEventType, Data: map[string]interface{}{
+			"form_id":         "form-1",
+			"title":           "Feedback",
+			"frequency":       "weekly",
+			"period_start":    "2024-03-04T08:00:00Z",
+			"period_end":      "2024-03-11T08:00:00Z",
+			"timezone":        "Europe/Berlin",
+			"recipients":      []string{"owner@example.com", "team@example.com"},
+			"total_responses": 12,
+			"completion_rate": 87.5,
+			"download_url":    "https://storage.example.com/reports/form-1.html?signature=abc",
+		}},
+		{ID: "e2", EventType: ReportReadyEventType, Data: map[string]interface{}{"form_id": "form-1"}},
+	}
+	if err := n.HandleBatch(context.Background(), batch); err != nil {
+		t.Fatal(err)
+	}
+	if len(sender.sent) != 2 || sender.sent[0].To != "owner@example.com" || sender.sent[1].To != "team@example.com" {
+		t.Fatalf("sent %+v, want the report to both recipients", sender.sent)
+	}
+
+	report := sender.sent[0]
+	if report.Subject != "Feedback weekly report" {
+		t.Errorf("report subject = %q", report.Subject)
+	}
+	for _, want := range []string{
+		"for 4 Mar 2024 09:00 to 11 Mar 2024 09:00 CET",
+		"Responses: 12\n",
+		"Completion rate: 87.5%\n",
+		"https://storage.example.com/reports/form-1.html?signature=abc",
+	} {
+		if !strings.Contains(report.Body, want) {
+			t.Errorf("report email does not contain %q:\n%s", want, report.Body)
+		}
+	}
+}
+
+func TestBuildMessage(t *testing.T) {
+	message, err := buildMessage("X-Form <no-reply@xform.local>", Email{
+		To:      "owner@example.com",
+		ReplyTo: "jane@example.com",
+		Subject: "New response to Café survey",
+		Body:    "line one\nline two\n",
+	}, time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "From: X-Form <no-reply@xform.local>\r\n" +
+		"To: owner@example.com\r\n" +
+		"Reply-To: jane@example.com\r\n" +
+		"Subject: =?utf-8?q?New_response_to_Caf=C3=A9_survey?=\r\n" +
+		"Date: Fri, 01 Mar 2024 12:00:00 +0000\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=\"utf-8\"\r\n" +
+		"Content-Transfer-Encoding: 8bit\r\n" +
+		"\r\n" +
+		"line one\r\nline two\r\n"
+	if string(message) != want {
+		t.Errorf("message =\n%q\nwant\n%q", message, want)
+	}
+
+	if _, err := buildMessage("no-reply@xform.local", Email{To: "owner@example.com\r\nBcc: all@example.com"}, time.Now()); err == nil {
+		t.Error("a header with a line break was accepted")
+	}
+}
+
+func TestBuildMessageWithAttachments(t *testing.T) {
+	pdf := bytes.Repeat([]byte("%PDF receipt "), 20)
+	message, err := buildMessage("no-reply@xform.local", Email{
+		To:          "jane@example.com",
+		Subject:     "Your response to Feedback",
+		Body:        "Thanks\n",
+		Attachments: []Attachment{{Name: "receipt-r1.pdf", ContentType: "application/pdf", Data: pdf}},
+	}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := mail.ReadMessage(bytes.NewReader(message))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("content type = %q, want multipart/mixed", parsed.Header.Get("Content-Type"))
+	}
+	parts := multipart.NewReader(parsed.Body, params["boundary"])
+
+	text, err := parts.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(text)
+	if !strings.HasPrefix(text.Header.Get("Content-Type"), "text/plain") || string(body) != "Thanks\r\n" {
+		t.Errorf("first part = %q %q, want the text", text.Header.Get("Content-Type"), body)
+	}
+
+	attachment, err := parts.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attachment.FileName() != "receipt-r1.pdf" || !strings.HasPrefix(attachment.Header.Get("Content-Type"), "application/pdf") {
+		t.Errorf("attachment = %q %q, want the receipt", attachment.FileName(), attachment.Header.Get("Content-Type"))
+	}
+	encoded, _ := io.ReadAll(attachment)
+	for _, line := range strings.Split(strings.TrimSpace(string(encoded)), "\r\n") {
+		if len(line) > 76 {
+			t.Errorf("base64 line of %d characters, want at most 76", len(line))
+		}
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(encoded), "\r\n", ""))
+	if err != nil || !bytes.Equal(decoded, pdf) {
+		t.Errorf("attachment decodes to %q, %v; want the receipt", decoded, err)
+	}
+	if _, err := parts.NextPart(); err != io.EOF {
+		t.Errorf("more parts than the text and the receipt: %v", err)
+	}
+}
@@ -0,0 +1,247 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+)
+
+// Email is a plain text email, with its attachments
+type Email struct {
+	To          string
+	ReplyTo     string
+	Subject     string
+	Body        string
+	Attachments []Attachment
+}
+
+// Attachment is a file attached to an email
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Sender delivers emails
+type Sender interface {
+	Send(ctx context.Context, email Email) error
+}
+
+// NewSender returns the sender selected by cfg.Sender
+func NewSender(cfg config.NotificationsConfig, logger *zap.Logger) (Sender, error) {
+	switch cfg.Sender {
+	case "smtp":
+		return NewSMTPSender(cfg.SMTP, cfg.From), nil
+	case "console":
+		return NewConsoleSender(logger), nil
+	default:
+		return nil, fmt.Errorf("unknown notification sender %q", cfg.Sender)
+	}
+}
+
+// SMTPSender sends emails through an SMTP server, upgrading the connection
+// with STARTTLS when the server offers it
+type SMTPSender struct {
+	addr     string
+	host     string
+	from     string
+	username string
+	password string
+	timeout  time.Duration
+}
+
+// NewSMTPSender creates a sender for the SMTP server of cfg, sending from from
+func NewSMTPSender(cfg config.SMTPConfig, from string) *SMTPSender {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &SMTPSender{
+		addr:     net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		host:     cfg.Host,
+		from:     from,
+		username: cfg.Username,
+		password: cfg.Password,
+		timeout:  timeout,
+	}
+}
+
+// Send delivers email in a single SMTP session
+func (s *SMTPSender) Send(ctx context.Context, email Email) error {
+	from, err := mail.ParseAddress(s.from)
+	if err != nil {
+		return fmt.Errorf("invalid from address: %w", err)
+	}
+	to, err := mail.ParseAddress(email.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient: %w", err)
+	}
+	message, err := buildMessage(s.from, email, time.Now())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.host, MinVersion: tls.VersionTLS12}); err != nil {
+			return fmt.Errorf("SMTP STARTTLS failed: %w", err)
+		}
+	}
+	if s.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("SMTP MAIL FROM failed: %w", err)
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return fmt.Errorf("SMTP RCPT TO failed: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP DATA failed: %w", err)
+	}
+	if _, err := w.Write(message); err != nil {
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("SMTP server rejected the email: %w", err)
+	}
+	return client.Quit()
+}
+
+// buildMessage formats email as a UTF-8 plain text message, or a
+// multipart/mixed message of the text and the attachments when it has any
+func buildMessage(from string, email Email, date time.Time) ([]byte, error) {
+	for _, value := range []string{email.To, email.ReplyTo, email.Subject} {
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, fmt.Errorf("email header %q contains a line break", value)
+		}
+	}
+	for _, attachment := range email.Attachments {
+		if strings.ContainsAny(attachment.Name+attachment.ContentType, "\r\n") {
+			return nil, fmt.Errorf("attachment %q contains a line break", attachment.Name)
+		}
+	}
+
+	var b bytes.Buffer
+	header := func(name, value string) {
+		fmt.Fprintf(&b, "%s: %s\r\n", name, value)
+	}
+	header("From", from)
+	header("To", email.To)
+	if email.ReplyTo != "" {
+		header("Reply-To", email.ReplyTo)
+	}
+	header("Subject", mime.QEncoding.Encode("utf-8", email.Subject))
+	header("Date", date.Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+
+	body := strings.ReplaceAll(strings.ReplaceAll(email.Body, "\r\n", "\n"), "\n", "\r\n")
+	if len(email.Attachments) == 0 {
+		header("Content-Type", `text/plain; charset="utf-8"`)
+		header("Content-Transfer-Encoding", "8bit")
+		b.WriteString("\r\n")
+		b.WriteString(body)
+		return b.Bytes(), nil
+	}
+
+	parts := multipart.NewWriter(&b)
+	header("Content-Type", mime.FormatMediaType("multipart/mixed", map[string]string{"boundary": parts.Boundary()}))
+	b.WriteString("\r\n")
+
+	text, err := parts.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {`text/plain; charset="utf-8"`},
+		"Content-Transfer-Encoding": {"8bit"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	io.WriteString(text, body)
+
+	for _, attachment := range email.Attachments {
+		contentType := attachment.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		part, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType(contentType, map[string]string{"name": attachment.Name})},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		// Base64 lines are at most 76 characters
+		encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+		for len(encoded) > 76 {
+			io.WriteString(part, encoded[:76]+"\r\n")
+			encoded = encoded[76:]
+		}
+		io.WriteString(part, encoded+"\r\n")
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// ConsoleSender logs emails instead of sending them, for development
+type ConsoleSender struct {
+	logger *zap.Logger
+}
+
+// NewConsoleSender creates a sender logging emails to logger
+func NewConsoleSender(logger *zap.Logger) *ConsoleSender {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &ConsoleSender{logger: logger}
+}
+
+// Send logs email
+func (s *ConsoleSender) Send(_ context.Context, email Email) error {
+	attachments := make([]string, 0, len(email.Attachments))
+	for _, attachment := range email.Attachments {
+		attachments = append(attachments, fmt.Sprintf("%s (%d bytes)", attachment.Name, len(attachment.Data)))
+	}
+	s.logger.Info("Notification email",
+		zap.String("to", email.To),
+		zap.String("reply_to", email.ReplyTo),
+		zap.String("subject", email.Subject),
+		zap.String("body", email.Body),
+		zap.Strings("attachments", attachments))
+	return nil
+}
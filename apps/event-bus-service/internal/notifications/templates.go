@@ -0,0 +1,242 @@
+package notifications
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Kinds of notification email, each with its own template
+const (
+	KindOwnerResponse  = "owner_response"
+	KindRespondentCopy = "respondent_copy"
+	KindFormPublished  = "form_published"
+	KindOwnerDigest    = "owner_digest"
+	KindTest           = "test"
+	KindReport         = "report"
+	// KindThrottleEngaged and KindThrottleReleased tell the owner a surge
+	// of submissions throttled their form, and that it normalized
+	KindThrottleEngaged  = "throttle_engaged"
+	KindThrottleReleased = "throttle_released"
+)
+
+// TemplateData is the data the notification templates are executed with
+type TemplateData struct {
+	FormID    string
+	FormTitle string
+	// Link is a deep link to the form, or to the response for its owner
+	Link        string
+	ResponseID  string
+	SubmittedAt time.Time
+	// Summary lists the answers of the response, up to maxSummaryAnswers
+	Summary []SummaryLine
+	// MoreAnswers is the number of answers left out of Summary
+	MoreAnswers int
+	// Pending is the number of responses an owner email stands for beyond
+	// the one it is about, held back by the rate limit
+	Pending int
+
+	// Report emails: the period of the report, in the time zone of its
+	// schedule, and its figures
+	Frequency      string
+	PeriodStart    time.Time
+	PeriodEnd      time.Time
+	Responses      int
+	CompletionRate float64
+
+	// Throttle emails: the submissions per minute the decision was made on
+	RatePerMinute     float64
+	BaselinePerMinute float64
+}
+
+// SummaryLine is one answer of a response summary
+type SummaryLine struct {
+	Question string
+	Answer   string
+}
+
+// templateSources define a "subject" and a "body" template per kind
+var templateSources = map[string]string{
+	KindOwnerResponse: `
+{{define "subject"}}New response to {{.FormTitle}}{{end}}
+{{define "body"}}Your form "{{.FormTitle}}" received a new response on {{.SubmittedAt.Format "2 Jan 2006 15:04 MST"}}.
+{{if .Pending}}
+{{.Pending}} more responses arrived while notifications were paused to avoid flooding your inbox.
+{{end}}
+{{template "summary" .}}
+View the response: {{.Link}}
+{{end}}`,
+
+	KindRespondentCopy: `
+{{define "subject"}}Your response to {{.FormTitle}}{{end}}
+{{define "body"}}Thank you for responding to "{{.FormTitle}}". This is a copy of your answers, submitted on {{.SubmittedAt.Format "2 Jan 2006 15:04 MST"}}.
+
+{{template "summary" .}}
+Form: {{.Link}}
+{{end}}`,
+
+	KindFormPublished: `
+{{define "subject"}}{{.FormTitle}} is published{{end}}
+{{define "body"}}Your form "{{.FormTitle}}" is published and accepting responses.
+
+Share it: {{.Link}}
+{{end}}`,
+
+	KindOwnerDigest: `
+{{define "subject"}}{{.Pending}} new responses to {{.FormTitle}}{{end}}
+{{define "body"}}Your form "{{.FormTitle}}" received {{.Pending}} responses that were not notified one by one, to avoid flooding your inbox.
+
+View the responses: {{.Link}}
+{{end}}`,
+
+	KindReport: `
+{{define "subject"}}{{.FormTitle}} {{.Frequency}} report{{end}}
+{{define "body"}}The {{.Frequency}} report of your form "{{.FormTitle}}" for {{.PeriodStart.Format "2 Jan 2006 15:04"}} to {{.PeriodEnd.Format "2 Jan 2006 15:04 MST"}} is ready.
+
+Responses: {{.Responses}}
+Completion rate: {{printf "%.1f" .CompletionRate}}%
+
+Download the report: {{.Link}}
+The link expires in 7 days.
+{{end}}`,
+
+	KindThrottleEngaged: `
+{{define "subject"}}Unusual submissions to {{.FormTitle}}: CAPTCHA enabled{{end}}
+{{define "body"}}Your form "{{.FormTitle}}" is receiving {{printf "%.1f" .RatePerMinute}} submissions a minute, against {{printf "%.1f" .BaselinePerMinute}} usually. To protect it from automated submissions, respondents must pass a CAPTCHA and each IP address may submit only a few responses a minute until the rate normalizes.
+
+No action is needed. You can tune or disable this protection in the settings of the form: {{.Link}}
+{{end}}`,
+
+	KindThrottleReleased: `
+{{define "subject"}}Submissions to {{.FormTitle}} are back to normal{{end}}
+{{define "body"}}Submissions to your form "{{.FormTitle}}" are back to {{printf "%.1f" .RatePerMinute}} a minute, so the CAPTCHA and the stricter limit enabled during the surge are lifted.
+
+Form settings: {{.Link}}
+{{end}}`,
+
+	KindTest: `
+{{define "subject"}}Test notification for {{.FormTitle}}{{end}}
+{{define "body"}}This is a test of the notifications of your form "{{.FormTitle}}". Notifications of new responses will be sent to this address.
+
+Form: {{.Link}}
+{{end}}`,
+}
+
+// cardTemplateSources define the "title", "text" and "action" of the chat
+// cards of each kind. Answers are listed as facts of the card, and only for
+// channels that include them.
+var cardTemplateSources = map[string]string{
+	KindOwnerResponse: `
+{{define "title"}}New response to {{.FormTitle}}{{end}}
+{{define "text"}}"{{.FormTitle}}" received a new response on {{.SubmittedAt.Format "2 Jan 2006 15:04 MST"}}.{{end}}
+{{define "action"}}View the response{{end}}`,
+
+	KindFormPublished: `
+{{define "title"}}{{.FormTitle}} is published{{end}}
+{{define "text"}}"{{.FormTitle}}" is published and accepting responses.{{end}}
+{{define "action"}}Open the form{{end}}`,
+
+	KindThrottleEngaged: `
+{{define "title"}}Unusual submissions to {{.FormTitle}}{{end}}
+{{define "text"}}"{{.FormTitle}}" is receiving {{printf "%.1f" .RatePerMinute}} submissions a minute, against {{printf "%.1f" .BaselinePerMinute}} usually. Respondents must pass a CAPTCHA until the rate normalizes.{{end}}
+{{define "action"}}Form settings{{end}}`,
+
+	KindThrottleReleased: `
+{{define "title"}}Submissions to {{.FormTitle}} are back to normal{{end}}
+{{define "text"}}Submissions to "{{.FormTitle}}" are back to {{printf "%.1f" .RatePerMinute}} a minute, so the CAPTCHA is lifted.{{end}}
+{{define "action"}}Form settings{{end}}`,
+
+	KindTest: `
+{{define "title"}}Test message for {{.FormTitle}}{{end}}
+{{define "text"}}This channel is set up to receive the notifications of "{{.FormTitle}}".{{end}}
+{{define "action"}}Open the form{{end}}`,
+}
+
+const summaryTemplate = `{{define "summary"}}{{range .Summary}}- {{.Question}}: {{.Answer}}
+{{end}}{{if .MoreAnswers}}...and {{.MoreAnswers}} more answers
+{{end}}{{end}}`
+
+// Templates renders notification emails and chat cards
+type Templates struct {
+	templates map[string]*template.Template
+	cards     map[string]*template.Template
+}
+
+// NewTemplates parses the notification templates
+func NewTemplates() (*Templates, error) {
+	t := &Templates{
+		templates: make(map[string]*template.Template, len(templateSources)),
+		cards:     make(map[string]*template.Template, len(cardTemplateSources)),
+	}
+	for kind, source := range templateSources {
+		tmpl, err := template.New(kind).Parse(summaryTemplate + source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s template: %w", kind, err)
+		}
+		t.templates[kind] = tmpl
+	}
+	for kind, source := range cardTemplateSources {
+		tmpl, err := template.New(kind).Parse(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s card template: %w", kind, err)
+		}
+		t.cards[kind] = tmpl
+	}
+	return t, nil
+}
+
+// Render returns the subject and body of an email of kind
+func (t *Templates) Render(kind string, data TemplateData) (subject, body string, err error) {
+	tmpl, ok := t.templates[kind]
+	if !ok {
+		return "", "", fmt.Errorf("no template for %s notifications", kind)
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&b, "subject", data); err != nil {
+		return "", "", fmt.Errorf("failed to render %s subject: %w", kind, err)
+	}
+	subject = strings.Join(strings.Fields(b.String()), " ")
+
+	b.Reset()
+	if err := tmpl.ExecuteTemplate(&b, "body", data); err != nil {
+		return "", "", fmt.Errorf("failed to render %s body: %w", kind, err)
+	}
+	return subject, strings.TrimSpace(b.String()) + "\n", nil
+}
+
+// RenderCard returns the chat card of kind, with the answers of the summary
+// as facts. Redacting answers is up to the caller, which leaves the summary
+// out of data.
+func (t *Templates) RenderCard(kind string, data TemplateData) (ChannelMessage, error) {
+	tmpl, ok := t.cards[kind]
+	if !ok {
+		return ChannelMessage{}, fmt.Errorf("no card template for %s notifications", kind)
+	}
+
+	parts := make(map[string]string, 3)
+	var b bytes.Buffer
+	for _, name := range []string{"title", "text", "action"} {
+		b.Reset()
+		if err := tmpl.ExecuteTemplate(&b, name, data); err != nil {
+			return ChannelMessage{}, fmt.Errorf("failed to render %s card %s: %w", kind, name, err)
+		}
+		parts[name] = strings.Join(strings.Fields(b.String()), " ")
+	}
+
+	message := ChannelMessage{
+		Title:     parts["title"],
+		Text:      parts["text"],
+		Link:      data.Link,
+		LinkLabel: parts["action"],
+	}
+	for _, line := range data.Summary {
+		message.Facts = append(message.Facts, Fact{Name: line.Question, Value: line.Answer})
+	}
+	if data.MoreAnswers > 0 {
+		message.Facts = append(message.Facts, Fact{Name: "More answers", Value: fmt.Sprintf("%d", data.MoreAnswers)})
+	}
+	return message, nil
+}
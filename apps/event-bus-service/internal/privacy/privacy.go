@@ -0,0 +1,104 @@
+// Package privacy carries out the data subject requests of the gateway: the
+// erasure or export of everything tied to a user. The worker consumes the
+// requests, anonymizes or reads the response projection itself, calls the
+// internal privacy endpoints of the form and collaboration services, and
+// publishes a completion event per participant. The relay posts those
+// completion events to the gateway, which aggregates the request status.
+// Both are at least once: a participant asked twice removes nothing the
+// second time, and the gateway ignores repeated completions.
+package privacy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/projections"
+)
+
+// Event types of data subject requests
+const (
+	ErasureRequestedEventType = "privacy.erasure.requested"
+	ExportRequestedEventType  = "privacy.export.requested"
+	ErasureCompletedEventType = "privacy.erasure.completed"
+	ExportCompletedEventType  = "privacy.export.completed"
+)
+
+// Request kinds
+const (
+	KindErasure = "erasure"
+	KindExport  = "export"
+)
+
+// Participants holding user data
+const (
+	ParticipantFormService          = "form-service"
+	ParticipantResponseStore        = "response-store"
+	ParticipantCollaborationService = "collaboration-service"
+)
+
+// eventSource is the source of the completion events the worker publishes
+const eventSource = "event-bus-service"
+
+// errInvalidEvent marks events that can never be carried out
+var errInvalidEvent = errors.New("invalid privacy event")
+
+// Request is the payload of a requested event
+type Request struct {
+	RequestID string `json:"request_id"`
+	Kind      string `json:"kind"`
+	UserID    string `json:"user_id"`
+	Email     string `json:"email,omitempty"`
+	// Participants are the participants the gateway waits for
+	Participants []string `json:"participants"`
+}
+
+// Completion is the payload of a completed event: what one participant
+// removed or exported, as counts rather than content
+type Completion struct {
+	RequestID   string           `json:"request_id"`
+	Kind        string           `json:"kind"`
+	Participant string           `json:"participant"`
+	Counts      map[string]int64 `json:"counts,omitempty"`
+	DownloadURL string           `json:"download_url,omitempty"`
+	ExpiresAt   *time.Time       `json:"expires_at,omitempty"`
+	// Error is set when the participant failed every attempt
+	Error       string    `json:"error,omitempty"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// Responses is the response projection, whose respondent IDs are the user IDs
+// of logged-in respondents
+type Responses interface {
+	AnonymizeRespondent(ctx context.Context, respondentID string) (int64, error)
+	RespondentSubmissions(ctx context.Context, respondentID string) ([]*projections.Submission, error)
+}
+
+// Publisher publishes the completion events
+type Publisher interface {
+	PublishMessage(ctx context.Context, message *kafka.Message) error
+}
+
+// decode unmarshals the data of message into v
+func decode(message *kafka.Message, v interface{}) error {
+	var payload []byte
+	switch data := message.Data.(type) {
+	case []byte:
+		payload = data
+	case json.RawMessage:
+		payload = data
+	default:
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return fmt.Errorf("%w: %v", errInvalidEvent, err)
+		}
+		payload = encoded
+	}
+	if err := json.Unmarshal(payload, v); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidEvent, err)
+	}
+	return nil
+}
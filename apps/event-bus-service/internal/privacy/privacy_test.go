@@ -0,0 +1,254 @@
+package privacy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/projections"
+)
+
+// memoryResponses holds the respondent of each response
+type memoryResponses struct {
+	respondents map[string]string
+}
+
+func (r *memoryResponses) AnonymizeRespondent(_ context.Context, respondentID string) (int64, error) {
+	var n int64
+	for response, respondent := range r.respondents {
+		if respondent == respondentID {
+			r.respondents[response] = ""
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *memoryResponses) RespondentSubmissions(_ context.Context, respondentID string) ([]*projections.Submission, error) {
+	var submissions []*projections.Submission
+	for response, respondent := range r.respondents {
+		if respondent == respondentID {
+			submissions = append(submissions, &projections.Submission{ResponseID: response})
+		}
+	}
+	return submissions, nil
+}
+
+// fakeServices fails the first failures calls of each participant
+type fakeServices struct {
+	failures map[string]int
+	calls    map[string]int
+	exported int
+}
+
+func (s *fakeServices) call(participant string) error {
+	s.calls[participant]++
+	if s.calls[participant] <= s.failures[participant] {
+		return errors.New(participant + " answered 503")
+	}
+	return nil
+}
+
+func (s *fakeServices) Erase(_ context.Context, participant string, _ Request) (*Result, error) {
+	if err := s.call(participant); err != nil {
+		return nil, err
+	}
+	return &Result{Counts: map[string]int64{"removed": 1}}, nil
+}
+
+func (s *fakeServices) Export(_ context.Context, _ Request, submissions []*projections.Submission) (*Result, error) {
+	if err := s.call(ParticipantFormService); err != nil {
+		return nil, err
+	}
+	s.exported = len(submissions)
+	return &Result{Counts: map[string]int64{"forms": 2}, DownloadURL: "https://files.example.com/export.json"}, nil
+}
+
+type recordingPublisher struct {
+	messages []*kafka.Message
+}
+
+func (p *recordingPublisher) PublishMessage(_ context.Context, message *kafka.Message) error {
+	p.messages = append(p.messages, message)
+	return nil
+}
+
+func (p *recordingPublisher) completions() map[string]Completion {
+	completions := make(map[string]Completion)
+	for _, message := range p.messages {
+		completion := message.Data.(Completion)
+		completions[completion.Participant] = completion
+	}
+	return completions
+}
+
+func testConfig() config.PrivacyConfig {
+	return config.PrivacyConfig{
+		RequestTopics:    []string{"app.privacy.erasure.requested", "app.privacy.export.requested"},
+		CompletionTopics: []string{"app.privacy.erasure.completed", "app.privacy.export.completed"},
+		GroupID:          "event-bus-privacy",
+		RetryAttempts:    1,
+		RetryBackoff:     time.Millisecond,
+		Timeout:          time.Second,
+		CallbackToken:    "token",
+	}
+}
+
+func requestMessage(eventType string, req Request) *kafka.Message {
+	data, _ := json.Marshal(req)
+	return &kafka.Message{ID: "event-1", EventType: eventType, Data: json.RawMessage(data)}
+}
+
+func TestErasureCompletesEveryParticipant(t *testing.T) {
+	responses := &memoryResponses{respondents: map[string]string{"r1": "user-1", "r2": "user-1", "r3": "user-2"}}
+	services := &fakeServices{
+		failures: map[string]int{ParticipantFormService: 1, ParticipantCollaborationService: 5},
+		calls:    map[string]int{},
+	}
+	publisher := &recordingPublisher{}
+	worker := NewWorker(testConfig(), responses, services, publisher, nil)
+
+	req := Request{RequestID: "req-1", Kind: KindErasure, UserID: "user-1", Participants: []string{
+		ParticipantFormService, ParticipantResponseStore, ParticipantCollaborationService, "billing",
+	}}
+	if err := worker.HandleBatch(context.Background(), []*kafka.Message{requestMessage(ErasureRequestedEventType, req)}); err != nil {
+		t.Fatal(err)
+	}
+
+	completions := publisher.completions()
+	if len(completions) != 4 {
+		t.Fatalf("completions = %v, want one per participant", completions)
+	}
+	if c := completions[ParticipantResponseStore]; c.Counts["responses_anonymized"] != 2 || c.Error != "" {
+		t.Errorf("response store completion = %+v, want 2 responses anonymized", c)
+	}
+	if responses.respondents["r3"] != "user-2" {
+		t.Error("the responses of another respondent were anonymized")
+	}
+	if c := completions[ParticipantFormService]; c.Counts["removed"] != 1 || c.Error != "" {
+		t.Errorf("form service completion = %+v, want success after a retry", c)
+	}
+	if c := completions[ParticipantCollaborationService]; c.Error == "" || services.calls[ParticipantCollaborationService] != 2 {
+		t.Errorf("collaboration completion = %+v after %d calls, want an error after the retry", c, services.calls[ParticipantCollaborationService])
+	}
+	if c := completions["billing"]; c.Error == "" {
+		t.Errorf("unknown participant completion = %+v, want an error", c)
+	}
+	if message := publisher.messages[0]; message.Topic != "app."+ErasureCompletedEventType || message.Key != "req-1" {
+		t.Errorf("completion published to %s with key %s", message.Topic, message.Key)
+	}
+
+	// Erasing again anonymizes nothing more
+	publisher.messages = nil
+	if err := worker.HandleBatch(context.Background(), []*kafka.Message{requestMessage(ErasureRequestedEventType, req)}); err != nil {
+		t.Fatal(err)
+	}
+	if c := publisher.completions()[ParticipantResponseStore]; c.Counts["responses_anonymized"] != 0 {
+		t.Errorf("second erasure anonymized %d responses", c.Counts["responses_anonymized"])
+	}
+}
+
+func TestExportArchivesSubmissions(t *testing.T) {
+	responses := &memoryResponses{respondents: map[string]string{"r1": "user-1", "r2": "user-1"}}
+	services := &fakeServices{calls: map[string]int{}}
+	publisher := &recordingPublisher{}
+	worker := NewWorker(testConfig(), responses, services, publisher, nil)
+
+	req := Request{RequestID: "req-2", Kind: KindExport, UserID: "user-1", Participants: []string{
+		ParticipantFormService, ParticipantResponseStore,
+	}}
+	messages := []*kafka.Message{
+		requestMessage(ExportRequestedEventType, req),
+		requestMessage(ExportRequestedEventType, Request{Kind: KindExport}),
+	}
+	if err := worker.HandleBatch(context.Background(), messages); err != nil {
+		t.Fatal(err)
+	}
+
+	completions := publisher.completions()
+	if len(completions) != 2 || services.exported != 2 {
+		t.Fatalf("completions = %v with %d submissions exported", completions, services.exported)
+	}
+	if c := completions[ParticipantFormService]; c.DownloadURL == "" || c.Counts["forms"] != 2 {
+		t.Errorf("form service completion = %+v, want the archive link", c)
+	}
+	if c := completions[ParticipantResponseStore]; c.Counts["submissions"] != 2 {
+		t.Errorf("response store completion = %+v, want 2 submissions", c)
+	}
+}
+
+func TestRelayPostsCompletionsToTheGateway(t *testing.T) {
+	var (
+		received []Completion
+		status   = http.StatusNoContent
+	)
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/internal/privacy/completions" || r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var completion Completion
+		if err := json.NewDecoder(r.Body).Decode(&completion); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received = append(received, completion)
+		w.WriteHeader(status)
+	}))
+	defer gateway.Close()
+
+	cfg := testConfig()
+	cfg.GatewayURL = gateway.URL + "/"
+	relay := NewRelay(cfg, nil)
+	if relay.GetGroupID() != "event-bus-privacy-relay" {
+		t.Errorf("relay group = %s", relay.GetGroupID())
+	}
+
+	completion := func(request string) *kafka.Message {
+		return &kafka.Message{EventType: ErasureCompletedEventType, Data: Completion{RequestID: request, Participant: ParticipantFormService}}
+	}
+	if err := relay.HandleBatch(context.Background(), []*kafka.Message{completion("req-1"), completion("req-2")}); err != nil {
+		t.Fatal(err)
+	}
+	if len(received) != 2 || received[1].RequestID != "req-2" {
+		t.Fatalf("gateway received %+v", received)
+	}
+
+	// Unknown requests are dropped, an unavailable gateway redelivers
+	status = http.StatusNotFound
+	if err := relay.HandleBatch(context.Background(), []*kafka.Message{completion("req-3")}); err != nil {
+		t.Errorf("rejected completion failed the batch: %v", err)
+	}
+	status = http.StatusServiceUnavailable
+	if err := relay.HandleBatch(context.Background(), []*kafka.Message{completion("req-4")}); err == nil {
+		t.Error("completion was dropped while the gateway is unavailable")
+	}
+}
+
+func TestServiceClientSendsTheServiceToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/internal/privacy/erasure" {
+			t.Errorf("path = %s, want /internal/privacy/erasure", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer service-token" {
+			t.Errorf("Authorization = %q, want the service token", got)
+		}
+		json.NewEncoder(w).Encode(Result{Counts: map[string]int64{"forms": 2}})
+	}))
+	defer server.Close()
+
+	client := NewServiceClient(map[string]string{ParticipantFormService: server.URL + "/"}, "service-token", time.Second)
+	result, err := client.Erase(context.Background(), ParticipantFormService, Request{RequestID: "req-1", UserID: "user-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Counts["forms"] != 2 {
+		t.Errorf("counts = %v, want the counts of the form service", result.Counts)
+	}
+}
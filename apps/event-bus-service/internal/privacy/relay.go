@@ -0,0 +1,114 @@
+package privacy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+)
+
+// Relay posts completion events to the gateway. It implements
+// kafka.BatchConsumerHandler.
+type Relay struct {
+	url    string
+	token  string
+	client *http.Client
+	logger *zap.Logger
+
+	topics  []string
+	groupID string
+}
+
+// NewRelay creates a relay to the gateway at cfg.GatewayURL. It consumes in a
+// group of its own, so completions waiting for the gateway do not hold the
+// requests up.
+func NewRelay(cfg config.PrivacyConfig, logger *zap.Logger) *Relay {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Relay{
+		url:     strings.TrimSuffix(cfg.GatewayURL, "/") + "/internal/privacy/completions",
+		token:   cfg.CallbackToken,
+		client:  &http.Client{Timeout: cfg.Timeout},
+		logger:  logger,
+		topics:  cfg.CompletionTopics,
+		groupID: cfg.GroupID + "-relay",
+	}
+}
+
+// GetTopics returns the completion topics
+func (r *Relay) GetTopics() []string {
+	return r.topics
+}
+
+// GetGroupID returns the consumer group the relay commits offsets in
+func (r *Relay) GetGroupID() string {
+	return r.groupID
+}
+
+// errRejected marks completions the gateway will never accept
+var errRejected = errors.New("completion rejected by the gateway")
+
+// HandleBatch posts each completion. Completions the gateway rejects, of
+// requests it does not know, are dropped; the batch fails, to be
+// redelivered, while the gateway is unreachable or refuses the token.
+func (r *Relay) HandleBatch(ctx context.Context, messages []*kafka.Message) error {
+	for _, message := range messages {
+		if message.EventType != ErasureCompletedEventType && message.EventType != ExportCompletedEventType {
+			continue
+		}
+
+		var completion Completion
+		err := decode(message, &completion)
+		if err == nil {
+			err = r.post(ctx, completion)
+		}
+		if errors.Is(err, errInvalidEvent) || errors.Is(err, errRejected) {
+			r.logger.Warn("Dropping privacy completion",
+				zap.String("event_id", message.ID),
+				zap.String("request_id", completion.RequestID),
+				zap.Error(err))
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Relay) post(ctx context.Context, completion Completion) error {
+	body, err := json.Marshal(completion)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+r.token)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to relay privacy completion: %w", err)
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: status %d", errRejected, resp.StatusCode)
+	default:
+		return fmt.Errorf("gateway answered %d to the privacy completion", resp.StatusCode)
+	}
+}
@@ -0,0 +1,106 @@
+package privacy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/projections"
+)
+
+// Result is what a service reports for a request
+type Result struct {
+	Counts      map[string]int64 `json:"counts"`
+	DownloadURL string           `json:"download_url,omitempty"`
+	ExpiresAt   *time.Time       `json:"expires_at,omitempty"`
+}
+
+// Services are the internal privacy endpoints of the services holding user
+// data
+type Services interface {
+	// Erase has participant remove the data of the user
+	Erase(ctx context.Context, participant string, req Request) (*Result, error)
+	// Export has the form service archive the forms of the user together
+	// with their submissions
+	Export(ctx context.Context, req Request, submissions []*projections.Submission) (*Result, error)
+}
+
+// ServiceClient calls the internal privacy endpoints over HTTP
+type ServiceClient struct {
+	urls   map[string]string
+	token  string
+	client *http.Client
+}
+
+// NewServiceClient creates a client of the services at urls, keyed by
+// participant name, authenticated by the service token
+func NewServiceClient(urls map[string]string, token string, timeout time.Duration) *ServiceClient {
+	trimmed := make(map[string]string, len(urls))
+	for participant, url := range urls {
+		trimmed[participant] = strings.TrimSuffix(url, "/")
+	}
+	return &ServiceClient{urls: trimmed, token: token, client: &http.Client{Timeout: timeout}}
+}
+
+// Erase posts the request to /internal/privacy/erasure of the participant
+func (c *ServiceClient) Erase(ctx context.Context, participant string, req Request) (*Result, error) {
+	return c.post(ctx, participant, "/internal/privacy/erasure", map[string]interface{}{
+		"request_id": req.RequestID,
+		"user_id":    req.UserID,
+		"email":      req.Email,
+	})
+}
+
+// Export posts the request and the submissions to /internal/privacy/export
+// of the form service
+func (c *ServiceClient) Export(ctx context.Context, req Request, submissions []*projections.Submission) (*Result, error) {
+	if submissions == nil {
+		submissions = []*projections.Submission{}
+	}
+	return c.post(ctx, ParticipantFormService, "/internal/privacy/export", map[string]interface{}{
+		"request_id":  req.RequestID,
+		"user_id":     req.UserID,
+		"email":       req.Email,
+		"submissions": submissions,
+	})
+}
+
+func (c *ServiceClient) post(ctx context.Context, participant, path string, payload interface{}) (*Result, error) {
+	baseURL, ok := c.urls[participant]
+	if !ok || baseURL == "" {
+		return nil, fmt.Errorf("no URL is configured for %s", participant)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call %s: %w", participant, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("%s answered %d: %s", participant, resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	var result Result
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid answer of %s: %w", participant, err)
+	}
+	return &result, nil
+}
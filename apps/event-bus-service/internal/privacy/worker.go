@@ -0,0 +1,224 @@
+package privacy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/projections"
+)
+
+// Worker carries out erasure and export requests. It implements
+// kafka.BatchConsumerHandler.
+type Worker struct {
+	responses Responses
+	services  Services
+	publisher Publisher
+	logger    *zap.Logger
+	now       func() time.Time
+
+	topics        []string
+	groupID       string
+	retryAttempts int
+	retryBackoff  time.Duration
+}
+
+// NewWorker creates a worker anonymizing or reading responses, calling
+// services for the other participants and publishing completions through
+// publisher
+func NewWorker(cfg config.PrivacyConfig, responses Responses, services Services, publisher Publisher, logger *zap.Logger) *Worker {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Worker{
+		responses:     responses,
+		services:      services,
+		publisher:     publisher,
+		logger:        logger,
+		now:           time.Now,
+		topics:        cfg.RequestTopics,
+		groupID:       cfg.GroupID,
+		retryAttempts: cfg.RetryAttempts,
+		retryBackoff:  cfg.RetryBackoff,
+	}
+}
+
+// GetTopics returns the request topics
+func (w *Worker) GetTopics() []string {
+	return w.topics
+}
+
+// GetGroupID returns the consumer group the worker commits offsets in
+func (w *Worker) GetGroupID() string {
+	return w.groupID
+}
+
+// HandleBatch carries out a batch of requests. A participant failing every
+// retry completes with an error, for the gateway to report; the batch only
+// fails, to be redelivered, when a completion can't be published.
+func (w *Worker) HandleBatch(ctx context.Context, messages []*kafka.Message) error {
+	for _, message := range messages {
+		var req Request
+		err := decode(message, &req)
+		if err == nil && (req.RequestID == "" || req.UserID == "") {
+			err = fmt.Errorf("%w: request_id and user_id are required", errInvalidEvent)
+		}
+
+		if err == nil {
+			switch message.EventType {
+			case ErasureRequestedEventType:
+				err = w.erase(ctx, req)
+			case ExportRequestedEventType:
+				err = w.export(ctx, req)
+			default:
+				continue
+			}
+		}
+
+		if errors.Is(err, errInvalidEvent) {
+			w.logger.Warn("Dropping privacy event",
+				zap.String("event_id", message.ID),
+				zap.String("event_type", message.EventType),
+				zap.Error(err))
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// erase has every participant remove the data of the user
+func (w *Worker) erase(ctx context.Context, req Request) error {
+	for _, participant := range req.Participants {
+		var result *Result
+		err := w.retry(ctx, func() error {
+			var err error
+			switch participant {
+			case ParticipantResponseStore:
+				var anonymized int64
+				anonymized, err = w.responses.AnonymizeRespondent(ctx, req.UserID)
+				result = &Result{Counts: map[string]int64{"responses_anonymized": anonymized}}
+			case ParticipantFormService, ParticipantCollaborationService:
+				result, err = w.services.Erase(ctx, participant, req)
+			default:
+				return fmt.Errorf("unknown participant %s", participant)
+			}
+			return err
+		})
+		if err := w.complete(ctx, ErasureCompletedEventType, KindErasure, req, participant, result, err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// export reads the submissions of the user and has the form service archive
+// them with the forms. The response store reports the submissions, and the
+// form service the forms and the download link.
+func (w *Worker) export(ctx context.Context, req Request) error {
+	var submissions []*projections.Submission
+	readErr := w.retry(ctx, func() error {
+		var err error
+		submissions, err = w.responses.RespondentSubmissions(ctx, req.UserID)
+		return err
+	})
+
+	var archive *Result
+	archiveErr := readErr
+	if readErr == nil {
+		archiveErr = w.retry(ctx, func() error {
+			var err error
+			archive, err = w.services.Export(ctx, req, submissions)
+			return err
+		})
+	}
+
+	for _, participant := range req.Participants {
+		var (
+			result *Result
+			err    error
+		)
+		switch participant {
+		case ParticipantResponseStore:
+			result, err = &Result{Counts: map[string]int64{"submissions": int64(len(submissions))}}, readErr
+		case ParticipantFormService:
+			result, err = archive, archiveErr
+		default:
+			err = fmt.Errorf("participant %s does not export", participant)
+		}
+		if err := w.complete(ctx, ExportCompletedEventType, KindExport, req, participant, result, err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// retry calls fn until it succeeds, at most retryAttempts more times
+func (w *Worker) retry(ctx context.Context, fn func() error) error {
+	var err error
+	for attempt := 0; attempt <= w.retryAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(w.retryBackoff * time.Duration(attempt)):
+			}
+		}
+		if err = fn(); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// complete publishes the completion of a participant
+func (w *Worker) complete(ctx context.Context, eventType, kind string, req Request, participant string, result *Result, cause error) error {
+	completion := Completion{
+		RequestID:   req.RequestID,
+		Kind:        kind,
+		Participant: participant,
+		CompletedAt: w.now().UTC(),
+	}
+	if cause != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		completion.Error = cause.Error()
+		w.logger.Error("Privacy request failed",
+			zap.String("request_id", req.RequestID),
+			zap.String("participant", participant),
+			zap.Error(cause))
+	} else if result != nil {
+		completion.Counts = result.Counts
+		completion.DownloadURL = result.DownloadURL
+		completion.ExpiresAt = result.ExpiresAt
+	}
+
+	err := w.publisher.PublishMessage(ctx, &kafka.Message{
+		ID:            fmt.Sprintf("%s:%s", req.RequestID, participant),
+		CorrelationID: req.RequestID,
+		EventType:     eventType,
+		Source:        eventSource,
+		Data:          completion,
+		Headers:       map[string]string{},
+		Metadata: kafka.MessageMetadata{
+			Timestamp:   completion.CompletedAt,
+			Version:     "1.0",
+			ContentType: "application/json",
+			Encoding:    "utf-8",
+		},
+		Topic: "app." + eventType,
+		Key:   req.RequestID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish the %s completion of request %s: %w", participant, req.RequestID, err)
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package processors
+
+import (
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+)
+
+// Consumer is the consumer group processors consume in, kafka.Client
+type Consumer interface {
+	AddRebalanceListener(listener kafka.RebalanceListener)
+	PausePartitions(groupID string, partitions map[string][]int32)
+	ResumePartitions(groupID string, partitions map[string][]int32)
+}
+
+// ProcessorAssignment is the partitions of the topics of a processor
+// assigned to this replica of the service
+type ProcessorAssignment struct {
+	Processor string `json:"processor" example:"cdc-processor"`
+	// GroupID is the consumer group of the processors, kafka.consumer.group_id
+	GroupID string `json:"group_id" example:"event-bus-service-group"`
+	// InstanceID is the static member ID of the replica, if any
+	InstanceID string `json:"instance_id,omitempty" example:"event-bus-0"`
+	State      string `json:"state" example:"running"`
+	// Partitions are the partitions assigned, by topic. They are paused
+	// while no running processor handles their topic.
+	Partitions map[string][]int32 `json:"partitions"`
+	// UpdatedAt is when partitions were last assigned or revoked
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// SetConsumer makes the manager follow the assignment of the consumer group
+// of the processors, kafka.consumer.group_id, and pause the partitions no
+// running processor handles
+func (pm *ProcessorManager) SetConsumer(consumer Consumer) {
+	pm.mutex.Lock()
+	pm.consumer = consumer
+	pm.mutex.Unlock()
+	consumer.AddRebalanceListener(pm)
+}
+
+// Assignment returns the partitions assigned to a processor
+func (pm *ProcessorManager) Assignment(name string) (ProcessorAssignment, error) {
+	mp, err := pm.get(name)
+	if err != nil {
+		return ProcessorAssignment{}, err
+	}
+
+	pm.mutex.RLock()
+	consumerConfig := pm.config.Kafka.Consumer
+	pm.mutex.RUnlock()
+	mp.mu.RLock()
+	state := mp.state
+	mp.mu.RUnlock()
+
+	assignment := ProcessorAssignment{
+		Processor:  name,
+		GroupID:    consumerConfig.GroupID,
+		InstanceID: consumerConfig.InstanceID,
+		State:      state,
+	}
+	mp.assignMu.Lock()
+	assignment.Partitions = copyPartitions(mp.partitions)
+	if !mp.assignedAt.IsZero() {
+		updatedAt := mp.assignedAt
+		assignment.UpdatedAt = &updatedAt
+	}
+	mp.assignMu.Unlock()
+	return assignment, nil
+}
+
+// PartitionsAssigned implements kafka.RebalanceListener. Of the partitions
+// assigned, only those no running processor handles are paused.
+func (pm *ProcessorManager) PartitionsAssigned(groupID string, partitions map[string][]int32) {
+	consumer, ok := pm.consumerOf(groupID)
+	if !ok {
+		return
+	}
+	for _, name := range pm.names() {
+		mp, err := pm.get(name)
+		if err != nil {
+			continue
+		}
+		if matched := mp.match(partitions); len(matched) > 0 {
+			mp.assign(matched, true)
+			pm.logger.Info("Processor partitions assigned",
+				zap.String("processor", name),
+				zap.String("group_id", groupID),
+				zap.Any("partitions", matched))
+		}
+	}
+	if idle := pm.idle(partitions); len(idle) > 0 {
+		consumer.PausePartitions(groupID, idle)
+		pm.logger.Info("Paused partitions of stopped processors",
+			zap.String("group_id", groupID),
+			zap.Any("partitions", idle))
+	}
+}
+
+// PartitionsRevoked implements kafka.RebalanceListener
+func (pm *ProcessorManager) PartitionsRevoked(groupID string, partitions map[string][]int32) {
+	if _, ok := pm.consumerOf(groupID); !ok {
+		return
+	}
+	for _, name := range pm.names() {
+		mp, err := pm.get(name)
+		if err != nil {
+			continue
+		}
+		if matched := mp.match(partitions); len(matched) > 0 {
+			mp.assign(matched, false)
+			pm.logger.Info("Processor partitions revoked",
+				zap.String("processor", name),
+				zap.String("group_id", groupID),
+				zap.Any("partitions", matched))
+		}
+	}
+}
+
+// consumerOf returns the consumer when groupID is the group of the processors
+func (pm *ProcessorManager) consumerOf(groupID string) (Consumer, bool) {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+	if pm.consumer == nil || groupID != pm.config.Kafka.Consumer.GroupID {
+		return nil, false
+	}
+	return pm.consumer, true
+}
+
+// pausePartitions pauses the partitions of a stopped processor no other
+// running processor handles
+func (pm *ProcessorManager) pausePartitions(mp *managedProcessor) {
+	pm.mutex.RLock()
+	consumer, groupID := pm.consumer, pm.config.Kafka.Consumer.GroupID
+	pm.mutex.RUnlock()
+	if consumer == nil {
+		return
+	}
+	if idle := pm.idle(mp.assigned()); len(idle) > 0 {
+		consumer.PausePartitions(groupID, idle)
+	}
+}
+
+// resumePartitions resumes the partitions of a started processor
+func (pm *ProcessorManager) resumePartitions(mp *managedProcessor) {
+	pm.mutex.RLock()
+	consumer, groupID := pm.consumer, pm.config.Kafka.Consumer.GroupID
+	pm.mutex.RUnlock()
+	if consumer == nil {
+		return
+	}
+	if partitions := mp.assigned(); len(partitions) > 0 {
+		consumer.ResumePartitions(groupID, partitions)
+	}
+}
+
+// idle returns the partitions whose topics some processor handles, but no
+// running one
+func (pm *ProcessorManager) idle(partitions map[string][]int32) map[string][]int32 {
+	idle := make(map[string][]int32)
+	for topic, list := range partitions {
+		handled, running := false, false
+		for _, name := range pm.names() {
+			mp, err := pm.get(name)
+			if err != nil || !mp.handles(topic) {
+				continue
+			}
+			handled = true
+			mp.mu.RLock()
+			running = running || mp.state == StateRunning
+			mp.mu.RUnlock()
+		}
+		if handled && !running {
+			idle[topic] = slices.Clone(list)
+		}
+	}
+	return idle
+}
+
+// handles reports whether the processor handles the events of topic
+func (mp *managedProcessor) handles(topic string) bool {
+	for _, pattern := range mp.processor.Topics() {
+		if matchTopic(pattern, topic) {
+			return true
+		}
+	}
+	return false
+}
+
+// match returns the partitions of the topics the processor handles
+func (mp *managedProcessor) match(partitions map[string][]int32) map[string][]int32 {
+	matched := make(map[string][]int32)
+	for topic, list := range partitions {
+		if mp.handles(topic) {
+			matched[topic] = slices.Clone(list)
+		}
+	}
+	return matched
+}
+
+// assign adds partitions to the assignment of the processor, or removes them
+func (mp *managedProcessor) assign(partitions map[string][]int32, add bool) {
+	mp.assignMu.Lock()
+	defer mp.assignMu.Unlock()
+	if mp.partitions == nil {
+		mp.partitions = make(map[string][]int32)
+	}
+	for topic, list := range partitions {
+		current := mp.partitions[topic]
+		for _, partition := range list {
+			if add && !slices.Contains(current, partition) {
+				current = append(current, partition)
+			} else if !add {
+				current = slices.DeleteFunc(current, func(p int32) bool { return p == partition })
+			}
+		}
+		if len(current) == 0 {
+			delete(mp.partitions, topic)
+			continue
+		}
+		slices.Sort(current)
+		mp.partitions[topic] = current
+	}
+	mp.assignedAt = time.Now()
+}
+
+// assigned returns a copy of the assignment of the processor
+func (mp *managedProcessor) assigned() map[string][]int32 {
+	mp.assignMu.Lock()
+	defer mp.assignMu.Unlock()
+	return copyPartitions(mp.partitions)
+}
+
+// copyPartitions returns a copy of partitions, never nil
+func copyPartitions(partitions map[string][]int32) map[string][]int32 {
+	copied := make(map[string][]int32, len(partitions))
+	for topic, list := range partitions {
+		copied[topic] = slices.Clone(list)
+	}
+	return copied
+}
@@ -1,42 +1,132 @@
 // Package processors provides event processing capabilities for the Event Bus Service
 // This package implements enterprise-grade event processors for handling various types
 // of CDC events, transformations, filtering, and routing with comprehensive monitoring.
+//
+// Processors are created from event_processing.processors by the factory
+// registered for their type, and the ProcessorManager starts and stops each
+// of them on its own.
 package processors
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"runtime/debug"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
 	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/events"
 	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+	"github.com/Mir00r/X-Form-Backend/shared/errreport"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
 	"go.uber.org/zap"
 )
 
-// EventProcessor defines the interface for processing events
-type EventProcessor interface {
-	ProcessEvent(ctx context.Context, event *events.CDCEvent) error
-	GetName() string
-	GetType() string
-	HealthCheck() error
-}
+// Processor defines the interface of event processors run by the manager
+type Processor interface {
+	// Name returns the name of the processor in the configuration
+	Name() string
+	// Topics returns the topics of the events the processor handles. A
+	// topic ending in ".*" matches every topic under it.
+	Topics() []string
+	// Start prepares the processor before it receives events. ctx bounds
+	// the start only.
+	Start(ctx context.Context) error
+	// Process processes an event
+	Process(ctx context.Context, event *events.CDCEvent) error
+	// Stop releases the processor; it receives no events until started again
+	Stop(ctx context.Context) error
+	// Health returns an error while the processor is unhealthy
+	Health() error
+}
+
+// Publisher publishes the messages of processors
+type Publisher interface {
+	PublishMessage(ctx context.Context, message *kafka.Message) error
+}
+
+// Processor states
+const (
+	StateRunning = "running"
+	StateStopped = "stopped"
+	// StateFailed is the state of a processor whose Start failed
+	StateFailed = "failed"
+)
+
+var (
+	// ErrProcessorNotFound is returned for a name no processor is configured with
+	ErrProcessorNotFound = errors.New("processor not found")
+	// ErrProcessorRunning is returned when starting a running processor
+	ErrProcessorRunning = errors.New("processor is already running")
+	// ErrProcessorNotRunning is returned when stopping a processor that is not running
+	ErrProcessorNotRunning = errors.New("processor is not running")
+)
+
+// stopTimeout bounds the Stop of each processor when the manager stops
+const stopTimeout = 10 * time.Second
 
 // ProcessorManager manages multiple event processors and routing
 type ProcessorManager struct {
 	config     *config.Config
 	logger     *zap.Logger
-	kafka      *kafka.Client
-	processors map[string]EventProcessor
-	routes     map[string][]string // topic -> processor names
+	processors map[string]*managedProcessor
 	metrics    *ProcessorMetrics
-	stopCh     chan struct{}
-	wg         sync.WaitGroup
-	mutex      sync.RWMutex
+	// reporter is the tracker of the events processors fail to process
+	reporter errreport.Reporter
+	// consumer is the consumer group of the processors, nil until set
+	consumer Consumer
+	stopCh   chan struct{}
+	wg       sync.WaitGroup
+	mutex    sync.RWMutex
+}
+
+// managedProcessor is a processor with its state and counters
+type managedProcessor struct {
+	processor Processor
+	typ       string
+	// enabled processors are started with the manager
+	enabled bool
+
+	// mu is held for reading while the processor processes an event, so a
+	// stopped processor has no event in progress
+	mu        sync.RWMutex
+	state     string
+	startedAt time.Time
+
+	processed atomic.Uint64
+	failed    atomic.Uint64
+	panics    atomic.Uint64
+
+	statsMu      sync.Mutex
+	lastError    string
+	lastActivity time.Time
+
+	// partitions of the topics of the processor assigned to the replica
+	assignMu   sync.Mutex
+	partitions map[string][]int32
+	assignedAt time.Time
+}
+
+// ProcessorStatus is the state and activity of a processor
+type ProcessorStatus struct {
+	Name   string   `json:"name" example:"cdc-processor"`
+	Type   string   `json:"type" example:"cdc"`
+	State  string   `json:"state" example:"running"`
+	Topics []string `json:"topics"`
+	// Healthy is false while the health check of a running processor fails
+	Healthy     bool   `json:"healthy"`
+	HealthError string `json:"health_error,omitempty"`
+	// LastError is the last error or panic of the processor
+	LastError       string     `json:"last_error,omitempty"`
+	EventsProcessed uint64     `json:"events_processed"`
+	EventsFailed    uint64     `json:"events_failed"`
+	Panics          uint64     `json:"panics"`
+	StartedAt       *time.Time `json:"started_at,omitempty"`
+	LastActivity    *time.Time `json:"last_activity,omitempty"`
 }
 
 // ProcessorMetrics contains Prometheus metrics for event processing
@@ -49,14 +139,15 @@ type ProcessorMetrics struct {
 	TransformationTime   prometheus.Histogram
 	RoutingDecisions     *prometheus.CounterVec
 	ErrorsByType         *prometheus.CounterVec
+	Panics               *prometheus.CounterVec
 }
 
 // CDCEventProcessor processes Change Data Capture events
 type CDCEventProcessor struct {
 	name            string
-	config          *config.EventProcessingConfig
+	topics          []string
 	logger          *zap.Logger
-	kafka           *kafka.Client
+	publisher       Publisher
 	transformations []Transformation
 	filters         []Filter
 	routes          []Route
@@ -66,9 +157,9 @@ type CDCEventProcessor struct {
 // FormEventProcessor processes form-related events
 type FormEventProcessor struct {
 	name        string
-	config      *config.EventProcessingConfig
+	topics      []string
 	logger      *zap.Logger
-	kafka       *kafka.Client
+	publisher   Publisher
 	formService string // Form service endpoint
 	metrics     *ProcessorMetrics
 }
@@ -76,9 +167,9 @@ type FormEventProcessor struct {
 // ResponseEventProcessor processes response-related events
 type ResponseEventProcessor struct {
 	name            string
-	config          *config.EventProcessingConfig
+	topics          []string
 	logger          *zap.Logger
-	kafka           *kafka.Client
+	publisher       Publisher
 	responseService string // Response service endpoint
 	metrics         *ProcessorMetrics
 }
@@ -86,12 +177,16 @@ type ResponseEventProcessor struct {
 // AnalyticsEventProcessor processes analytics events
 type AnalyticsEventProcessor struct {
 	name             string
-	config           *config.EventProcessingConfig
+	topics           []string
 	logger           *zap.Logger
-	kafka            *kafka.Client
+	publisher        Publisher
 	analyticsService string // Analytics service endpoint
-	aggregators      map[string]*EventAggregator
+	windows          map[string]time.Duration
+	defaultWindow    time.Duration
 	metrics          *ProcessorMetrics
+
+	mutex       sync.Mutex
+	aggregators map[string]*EventAggregator
 }
 
 // Transformation defines an event transformation
@@ -134,8 +229,10 @@ type ProcessingResult struct {
 	Metadata        map[string]interface{} `json:"metadata,omitempty"`
 }
 
-// NewProcessorManager creates a new processor manager
-func NewProcessorManager(cfg *config.Config, logger *zap.Logger, kafkaClient *kafka.Client) (*ProcessorManager, error) {
+// NewProcessorManager creates a new processor manager with the processors
+// of event_processing.processors, disabled ones included in the stopped
+// state. A processor of an unregistered type is an error.
+func NewProcessorManager(cfg *config.Config, logger *zap.Logger, publisher Publisher, metrics *ProcessorMetrics) (*ProcessorManager, error) {
 	if logger == nil {
 		logger = zap.NewNop()
 	}
@@ -143,15 +240,14 @@ func NewProcessorManager(cfg *config.Config, logger *zap.Logger, kafkaClient *ka
 	manager := &ProcessorManager{
 		config:     cfg,
 		logger:     logger,
-		kafka:      kafkaClient,
-		processors: make(map[string]EventProcessor),
-		routes:     make(map[string][]string),
-		metrics:    initProcessorMetrics(),
+		processors: make(map[string]*managedProcessor),
+		metrics:    metrics,
+		reporter:   errreport.NewNoop(),
 		stopCh:     make(chan struct{}),
 	}
 
 	// Initialize processors based on configuration
-	if err := manager.initializeProcessors(); err != nil {
+	if err := manager.initializeProcessors(publisher); err != nil {
 		return nil, fmt.Errorf("failed to initialize processors: %w", err)
 	}
 
@@ -161,15 +257,28 @@ func NewProcessorManager(cfg *config.Config, logger *zap.Logger, kafkaClient *ka
 	return manager, nil
 }
 
-// Start starts the processor manager and all processors
+// Start starts the processor manager and the enabled processors. A
+// processor failing to start is left in the failed state.
 func (pm *ProcessorManager) Start(ctx context.Context) error {
-	if pm.config.EventProcessing.Workers == 0 {
+	pm.mutex.RLock()
+	workers := pm.config.EventProcessing.Workers
+	pm.mutex.RUnlock()
+	if workers == 0 {
 		pm.logger.Info("Event processing is disabled (workers=0), skipping startup")
 		return nil
 	}
 
 	pm.logger.Info("Starting processor manager")
 
+	for _, name := range pm.names() {
+		if mp, err := pm.get(name); err != nil || !mp.enabled {
+			continue
+		}
+		if err := pm.StartProcessor(ctx, name); err != nil {
+			pm.logger.Error("Failed to start processor", zap.String("processor", name), zap.Error(err))
+		}
+	}
+
 	// Start health check monitoring
 	pm.wg.Add(1)
 	go pm.healthCheckLoop(ctx)
@@ -188,11 +297,155 @@ func (pm *ProcessorManager) Stop() error {
 	close(pm.stopCh)
 	pm.wg.Wait()
 
+	for _, name := range pm.names() {
+		ctx, cancel := context.WithTimeout(context.Background(), stopTimeout)
+		err := pm.StopProcessor(ctx, name)
+		cancel()
+		if err != nil && !errors.Is(err, ErrProcessorNotRunning) {
+			pm.logger.Error("Failed to stop processor", zap.String("processor", name), zap.Error(err))
+		}
+	}
+
 	pm.logger.Info("Processor manager stopped")
 	return nil
 }
 
-// ProcessEvent processes an event through the appropriate processors
+// StartProcessor starts a stopped or failed processor
+func (pm *ProcessorManager) StartProcessor(ctx context.Context, name string) error {
+	mp, err := pm.get(name)
+	if err != nil {
+		return err
+	}
+
+	err = func() error {
+		mp.mu.Lock()
+		defer mp.mu.Unlock()
+		if mp.state == StateRunning {
+			return ErrProcessorRunning
+		}
+		if err := pm.safely(mp, "start", func() error { return mp.processor.Start(ctx) }); err != nil {
+			mp.state = StateFailed
+			mp.recordError(err)
+			return err
+		}
+		mp.state = StateRunning
+		mp.startedAt = time.Now()
+		return nil
+	}()
+	if err != nil {
+		return err
+	}
+	pm.resumePartitions(mp)
+
+	pm.logger.Info("Processor started", zap.String("processor", name), zap.String("type", mp.typ))
+	return nil
+}
+
+// StopProcessor stops a running processor once the events it is processing
+// are done
+func (pm *ProcessorManager) StopProcessor(ctx context.Context, name string) error {
+	mp, err := pm.get(name)
+	if err != nil {
+		return err
+	}
+
+	mp.mu.Lock()
+	if mp.state != StateRunning {
+		mp.mu.Unlock()
+		return ErrProcessorNotRunning
+	}
+	// The processor is stopped even when its Stop fails, as it receives no
+	// more events
+	mp.state = StateStopped
+	mp.startedAt = time.Time{}
+	err = pm.safely(mp, "stop", func() error { return mp.processor.Stop(ctx) })
+	mp.mu.Unlock()
+	pm.pausePartitions(mp)
+	if err != nil {
+		mp.recordError(err)
+		return err
+	}
+
+	pm.logger.Info("Processor stopped", zap.String("processor", name))
+	return nil
+}
+
+// Statuses returns the status of every processor, sorted by name
+func (pm *ProcessorManager) Statuses() []ProcessorStatus {
+	names := pm.names()
+	statuses := make([]ProcessorStatus, 0, len(names))
+	for _, name := range names {
+		if mp, err := pm.get(name); err == nil {
+			statuses = append(statuses, pm.status(mp))
+		}
+	}
+	return statuses
+}
+
+// Status returns the status of a processor
+func (pm *ProcessorManager) Status(name string) (ProcessorStatus, error) {
+	mp, err := pm.get(name)
+	if err != nil {
+		return ProcessorStatus{}, err
+	}
+	return pm.status(mp), nil
+}
+
+func (pm *ProcessorManager) status(mp *managedProcessor) ProcessorStatus {
+	mp.mu.RLock()
+	status := ProcessorStatus{
+		Name:            mp.processor.Name(),
+		Type:            mp.typ,
+		State:           mp.state,
+		Topics:          mp.processor.Topics(),
+		EventsProcessed: mp.processed.Load(),
+		EventsFailed:    mp.failed.Load(),
+		Panics:          mp.panics.Load(),
+	}
+	if !mp.startedAt.IsZero() {
+		startedAt := mp.startedAt
+		status.StartedAt = &startedAt
+	}
+	mp.mu.RUnlock()
+
+	if status.State == StateRunning {
+		status.Healthy = true
+		if err := pm.safely(mp, "health", mp.processor.Health); err != nil {
+			status.Healthy = false
+			status.HealthError = err.Error()
+		}
+	}
+
+	mp.statsMu.Lock()
+	status.LastError = mp.lastError
+	if !mp.lastActivity.IsZero() {
+		lastActivity := mp.lastActivity
+		status.LastActivity = &lastActivity
+	}
+	mp.statsMu.Unlock()
+	return status
+}
+
+// ReloadConfig switches the manager to a reloaded configuration, picking up
+// changed worker counts and batch sizes. The processors themselves are read
+// from the configuration at startup only.
+func (pm *ProcessorManager) ReloadConfig(cfg *config.Config) error {
+	pm.mutex.Lock()
+	previous := pm.config.EventProcessing
+	pm.config = cfg
+	pm.mutex.Unlock()
+
+	if previous.Workers != cfg.EventProcessing.Workers || previous.BatchSize != cfg.EventProcessing.BatchSize {
+		pm.logger.Info("Event processing settings reloaded",
+			zap.Int("workers", cfg.EventProcessing.Workers),
+			zap.Int("batch_size", cfg.EventProcessing.BatchSize))
+	}
+	return nil
+}
+
+// ProcessEvent processes an event through the running processors whose
+// topics match it. A processor that fails or panics does not keep the
+// others from processing the event.
 func (pm *ProcessorManager) ProcessEvent(ctx context.Context, event *events.CDCEvent) (*ProcessingResult, error) {
 	start := time.Now()
 	defer func() {
@@ -218,28 +471,25 @@ func (pm *ProcessorManager) ProcessEvent(ctx context.Context, event *events.CDCE
 	}
 
 	// Process through each selected processor
-	for _, processorName := range processors {
-		pm.mutex.RLock()
-		processor, exists := pm.processors[processorName]
-		pm.mutex.RUnlock()
-
-		if !exists {
-			pm.logger.Warn("Processor not found", zap.String("processor", processorName))
+	for _, mp := range processors {
+		processorName := mp.processor.Name()
+		processorStart := time.Now()
+		processed, err := pm.process(ctx, mp, event)
+		if !processed {
 			continue
 		}
-
-		processorStart := time.Now()
-		if err := processor.ProcessEvent(ctx, event); err != nil {
+		if err != nil {
 			pm.logger.Error("Processor failed to process event",
 				zap.String("processor", processorName),
 				zap.String("event_id", event.ID),
+				zap.String("error_id", pm.reportError(ctx, processorName, event, err)),
 				zap.Error(err))
 
 			result.Success = false
 			result.Error = err.Error()
 			result.ProcessorName = processorName
 			pm.metrics.EventsFailed.Inc()
-			pm.metrics.ErrorsByType.WithLabelValues(processor.GetType(), "processing_error").Inc()
+			pm.metrics.ErrorsByType.WithLabelValues(mp.typ, "processing_error").Inc()
 			continue
 		}
 
@@ -261,138 +511,179 @@ func (pm *ProcessorManager) ProcessEvent(ctx context.Context, event *events.CDCE
 	return result, nil
 }
 
-// RegisterProcessor registers a new event processor
-func (pm *ProcessorManager) RegisterProcessor(processor EventProcessor) error {
-	pm.mutex.Lock()
-	defer pm.mutex.Unlock()
+// process processes an event with a processor, unless it is not running
+func (pm *ProcessorManager) process(ctx context.Context, mp *managedProcessor, event *events.CDCEvent) (bool, error) {
+	mp.mu.RLock()
+	defer mp.mu.RUnlock()
+	if mp.state != StateRunning {
+		return false, nil
+	}
 
-	name := processor.GetName()
-	if _, exists := pm.processors[name]; exists {
-		return fmt.Errorf("processor %s already registered", name)
+	err := pm.safely(mp, "process", func() error { return mp.processor.Process(ctx, event) })
+	mp.statsMu.Lock()
+	mp.lastActivity = time.Now()
+	mp.statsMu.Unlock()
+	if err != nil {
+		mp.failed.Add(1)
+		mp.recordError(err)
+		return true, err
 	}
+	mp.processed.Add(1)
+	return true, nil
+}
 
-	pm.processors[name] = processor
-	pm.logger.Info("Processor registered",
-		zap.String("name", name),
-		zap.String("type", processor.GetType()))
+// safely calls a method of a processor, turning a panic into an error so it
+// doesn't take down the other processors
+func (pm *ProcessorManager) safely(mp *managedProcessor, op string, fn func() error) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			stack := debug.Stack()
+			mp.panics.Add(1)
+			pm.metrics.Panics.WithLabelValues(mp.processor.Name()).Inc()
+			pm.logger.Error("Processor panicked",
+				zap.String("processor", mp.processor.Name()),
+				zap.String("operation", op),
+				zap.Any("panic", recovered),
+				zap.ByteString("stack", stack))
+			err = &processorPanic{processor: mp.processor.Name(), op: op, value: recovered, stack: string(stack)}
+		}
+	}()
+	return fn()
+}
 
-	return nil
+// processorPanic is the error of a processor that panicked
+type processorPanic struct {
+	processor string
+	op        string
+	value     interface{}
+	stack     string
 }
 
-// UnregisterProcessor unregisters an event processor
-func (pm *ProcessorManager) UnregisterProcessor(name string) error {
-	pm.mutex.Lock()
-	defer pm.mutex.Unlock()
+func (p *processorPanic) Error() string {
+	return fmt.Sprintf("processor %s panicked during %s: %v", p.processor, p.op, p.value)
+}
 
-	if _, exists := pm.processors[name]; !exists {
-		return fmt.Errorf("processor %s not found", name)
-	}
+// SetErrorReporter sets the tracker the events processors fail to process
+// are reported to, instead of none
+func (pm *ProcessorManager) SetErrorReporter(reporter errreport.Reporter) {
+	pm.reporter = reporter
+}
 
-	delete(pm.processors, name)
-	pm.logger.Info("Processor unregistered", zap.String("name", name))
+// reportError reports the failure of a processor to process an event with
+// its type and topic, at the offset of the Kafka message being consumed
+// when there is one, and returns the ID of the event reported
+func (pm *ProcessorManager) reportError(ctx context.Context, processor string, event *events.CDCEvent, err error) string {
+	message := &errreport.Message{ID: event.ID, EventType: event.Operation}
+	if consumed := errreport.MessageFrom(ctx); consumed != nil {
+		copied := *consumed
+		message = &copied
+		message.ID = event.ID
+	}
+	if event.Source != nil {
+		message.EventType = event.Source.Table + "." + event.Operation
+		if message.Topic == "" {
+			message.Topic = event.Source.Topic
+		}
+	}
 
-	return nil
+	report := &errreport.Event{Err: err, Message: message, Tags: map[string]string{"processor": processor}}
+	var panicked *processorPanic
+	if errors.As(err, &panicked) {
+		report.Err, report.Panic, report.Stack = errreport.PanicError(panicked.value), true, panicked.stack
+	}
+	return pm.reporter.Report(ctx, report)
 }
 
-// initializeProcessors initializes all configured processors
-func (pm *ProcessorManager) initializeProcessors() error {
-	// Initialize CDC processor
-	cdcProcessor := &CDCEventProcessor{
-		name:    "cdc-processor",
-		config:  &pm.config.EventProcessing,
-		logger:  pm.logger.Named("cdc-processor"),
-		kafka:   pm.kafka,
-		metrics: pm.metrics,
-	}
-	if err := cdcProcessor.initialize(); err != nil {
-		return fmt.Errorf("failed to initialize CDC processor: %w", err)
-	}
-	pm.processors[cdcProcessor.name] = cdcProcessor
-
-	// Initialize Form processor
-	formProcessor := &FormEventProcessor{
-		name:        "form-processor",
-		config:      &pm.config.EventProcessing,
-		logger:      pm.logger.Named("form-processor"),
-		kafka:       pm.kafka,
-		formService: "http://form-service:8080", // From service discovery
-		metrics:     pm.metrics,
-	}
-	pm.processors[formProcessor.name] = formProcessor
-
-	// Initialize Response processor
-	responseProcessor := &ResponseEventProcessor{
-		name:            "response-processor",
-		config:          &pm.config.EventProcessing,
-		logger:          pm.logger.Named("response-processor"),
-		kafka:           pm.kafka,
-		responseService: "http://response-service:8080", // From service discovery
-		metrics:         pm.metrics,
-	}
-	pm.processors[responseProcessor.name] = responseProcessor
-
-	// Initialize Analytics processor
-	analyticsProcessor := &AnalyticsEventProcessor{
-		name:             "analytics-processor",
-		config:           &pm.config.EventProcessing,
-		logger:           pm.logger.Named("analytics-processor"),
-		kafka:            pm.kafka,
-		analyticsService: "http://analytics-service:8080", // From service discovery
-		aggregators:      make(map[string]*EventAggregator),
-		metrics:          pm.metrics,
-	}
-	if err := analyticsProcessor.initialize(); err != nil {
-		return fmt.Errorf("failed to initialize analytics processor: %w", err)
-	}
-	pm.processors[analyticsProcessor.name] = analyticsProcessor
-
-	// Configure routing
-	pm.configureRouting()
+func (mp *managedProcessor) recordError(err error) {
+	mp.statsMu.Lock()
+	defer mp.statsMu.Unlock()
+	mp.lastError = err.Error()
+}
 
-	return nil
+// get returns the processor named name
+func (pm *ProcessorManager) get(name string) (*managedProcessor, error) {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+	mp, ok := pm.processors[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrProcessorNotFound, name)
+	}
+	return mp, nil
 }
 
-// configureRouting configures event routing based on configuration
-func (pm *ProcessorManager) configureRouting() {
-	// Route CDC events to appropriate processors
-	pm.routes["cdc.forms"] = []string{"cdc-processor", "form-processor", "analytics-processor"}
-	pm.routes["cdc.responses"] = []string{"cdc-processor", "response-processor", "analytics-processor"}
-	pm.routes["cdc.users"] = []string{"cdc-processor", "analytics-processor"}
-	pm.routes["cdc.analytics"] = []string{"analytics-processor"}
+// names returns the names of the processors, sorted
+func (pm *ProcessorManager) names() []string {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+	names := make([]string, 0, len(pm.processors))
+	for name := range pm.processors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// initializeProcessors creates the configured processors with the factories
+// of their types
+func (pm *ProcessorManager) initializeProcessors(publisher Publisher) error {
+	deps := Dependencies{Publisher: publisher, Metrics: pm.metrics}
+	for name, processorCfg := range pm.config.EventProcessing.Processors {
+		typ := processorCfg.Type
+		if typ == "" {
+			typ = name
+		}
+		factory, ok := lookup(typ)
+		if !ok {
+			return fmt.Errorf("processor %s: unknown type %q", name, typ)
+		}
 
-	// Route application events
-	pm.routes["app.form.created"] = []string{"form-processor", "analytics-processor"}
-	pm.routes["app.form.updated"] = []string{"form-processor", "analytics-processor"}
-	pm.routes["app.response.submitted"] = []string{"response-processor", "analytics-processor"}
-	pm.routes["app.user.registered"] = []string{"analytics-processor"}
+		deps.Logger = pm.logger.Named(name)
+		processor, err := factory(name, processorCfg, deps)
+		if err != nil {
+			return fmt.Errorf("failed to initialize processor %s: %w", name, err)
+		}
+		pm.processors[name] = &managedProcessor{
+			processor: processor,
+			typ:       typ,
+			enabled:   processorCfg.Enabled,
+			state:     StateStopped,
+		}
+	}
+	return nil
 }
 
-// getProcessorsForEvent determines which processors should handle an event
-func (pm *ProcessorManager) getProcessorsForEvent(event *events.CDCEvent) []string {
+// getProcessorsForEvent determines which processors should handle an event,
+// sorted by name
+func (pm *ProcessorManager) getProcessorsForEvent(event *events.CDCEvent) []*managedProcessor {
 	// Build topic key for routing
 	topicKey := event.Source.Topic
 	if event.Source.Table != "" {
 		topicKey = fmt.Sprintf("%s.%s", event.Source.Topic, event.Source.Table)
 	}
 
-	// Check exact match first
-	if processors, exists := pm.routes[topicKey]; exists {
-		return processors
-	}
-
-	// Check prefix matches
-	for route, processors := range pm.routes {
-		if strings.HasPrefix(topicKey, route) || strings.HasPrefix(route, topicKey) {
-			return processors
+	var matched []*managedProcessor
+	for _, name := range pm.names() {
+		mp, err := pm.get(name)
+		if err != nil {
+			continue
+		}
+		for _, topic := range mp.processor.Topics() {
+			if matchTopic(topic, event.Source.Topic) || matchTopic(topic, topicKey) {
+				matched = append(matched, mp)
+				break
+			}
 		}
 	}
+	return matched
+}
 
-	// Default fallback to CDC processor for all CDC events
-	if strings.HasPrefix(topicKey, "cdc.") || event.Source.Connector != "" {
-		return []string{"cdc-processor"}
+// matchTopic reports whether a topic of a processor matches topic. A
+// pattern ending in ".*" matches the topics under it.
+func matchTopic(pattern, topic string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(topic, prefix)
 	}
-
-	return []string{}
+	return pattern == topic
 }
 
 // healthCheckLoop performs periodic health checks on all processors
@@ -433,25 +724,21 @@ func (pm *ProcessorManager) metricsCollectionLoop(ctx context.Context) {
 	}
 }
 
-// performHealthChecks performs health checks on all processors
+// performHealthChecks performs health checks on all processors. Processors
+// that are not running score 0.
 func (pm *ProcessorManager) performHealthChecks() {
-	pm.mutex.RLock()
-	processors := make(map[string]EventProcessor)
-	for name, processor := range pm.processors {
-		processors[name] = processor
-	}
-	pm.mutex.RUnlock()
-
-	for name, processor := range processors {
+	for _, status := range pm.Statuses() {
 		healthScore := 1.0
-		if err := processor.HealthCheck(); err != nil {
-			pm.logger.Warn("Processor health check failed",
-				zap.String("processor", name),
-				zap.Error(err))
+		if !status.Healthy {
+			if status.State == StateRunning {
+				pm.logger.Warn("Processor health check failed",
+					zap.String("processor", status.Name),
+					zap.String("error", status.HealthError))
+			}
 			healthScore = 0.0
 		}
 
-		pm.metrics.ProcessorHealthScore.WithLabelValues(name, processor.GetType()).Set(healthScore)
+		pm.metrics.ProcessorHealthScore.WithLabelValues(status.Name, status.Type).Set(healthScore)
 	}
 }
 
@@ -463,8 +750,36 @@ func (pm *ProcessorManager) collectMetrics() {
 
 // CDC Event Processor Implementation
 
+// cdcSettings are the settings of cdc processors
+type cdcSettings struct {
+	AllowedTables     []string `mapstructure:"allowed_tables"`
+	AllowedOperations []string `mapstructure:"allowed_operations"`
+}
+
+// newCDCEventProcessor creates a cdc processor, handling the topics under
+// cdc. by default
+func newCDCEventProcessor(name string, cfg config.ProcessorConfig, deps Dependencies) (Processor, error) {
+	settings := cdcSettings{
+		AllowedTables:     []string{"forms", "responses", "users", "analytics"},
+		AllowedOperations: []string{"c", "u", "d"}, // create, update, delete
+	}
+	if err := decodeSettings(cfg.Settings, &settings); err != nil {
+		return nil, err
+	}
+
+	cep := &CDCEventProcessor{
+		name:      name,
+		topics:    topicsOr(cfg, "cdc.*"),
+		logger:    deps.Logger,
+		publisher: deps.Publisher,
+		metrics:   deps.Metrics,
+	}
+	cep.initialize(settings)
+	return cep, nil
+}
+
 // initialize initializes the CDC event processor
-func (cep *CDCEventProcessor) initialize() error {
+func (cep *CDCEventProcessor) initialize(settings cdcSettings) {
 	// Initialize transformations
 	cep.transformations = []Transformation{
 		&TableNameTransformation{},
@@ -474,8 +789,8 @@ func (cep *CDCEventProcessor) initialize() error {
 
 	// Initialize filters
 	cep.filters = []Filter{
-		&TableFilter{AllowedTables: []string{"forms", "responses", "users", "analytics"}},
-		&OperationFilter{AllowedOperations: []string{"c", "u", "d"}}, // create, update, delete
+		&TableFilter{AllowedTables: settings.AllowedTables},
+		&OperationFilter{AllowedOperations: settings.AllowedOperations},
 	}
 
 	// Initialize routes
@@ -484,12 +799,10 @@ func (cep *CDCEventProcessor) initialize() error {
 		&TopicRoute{SourceTopics: []string{"cdc.responses"}, TargetTopics: []string{"processed.responses"}},
 		&TopicRoute{SourceTopics: []string{"cdc.users"}, TargetTopics: []string{"processed.users"}},
 	}
-
-	return nil
 }
 
-// ProcessEvent processes a CDC event
-func (cep *CDCEventProcessor) ProcessEvent(ctx context.Context, event *events.CDCEvent) error {
+// Process processes a CDC event
+func (cep *CDCEventProcessor) Process(ctx context.Context, event *events.CDCEvent) error {
 	start := time.Now()
 	defer func() {
 		cep.metrics.TransformationTime.Observe(time.Since(start).Seconds())
@@ -557,29 +870,61 @@ func (cep *CDCEventProcessor) publishToTarget(ctx context.Context, event *events
 		},
 	}
 
-	return cep.kafka.PublishMessage(ctx, message)
+	return cep.publisher.PublishMessage(ctx, message)
 }
 
-// GetName returns the processor name
-func (cep *CDCEventProcessor) GetName() string {
+// Name returns the processor name
+func (cep *CDCEventProcessor) Name() string {
 	return cep.name
 }
 
-// GetType returns the processor type
-func (cep *CDCEventProcessor) GetType() string {
-	return "cdc"
+// Topics returns the topics the processor handles
+func (cep *CDCEventProcessor) Topics() []string {
+	return cep.topics
 }
 
-// HealthCheck performs a health check
-func (cep *CDCEventProcessor) HealthCheck() error {
+// Start starts the processor
+func (cep *CDCEventProcessor) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop stops the processor
+func (cep *CDCEventProcessor) Stop(ctx context.Context) error {
+	return nil
+}
+
+// Health performs a health check
+func (cep *CDCEventProcessor) Health() error {
 	// Check if all transformations and filters are healthy
 	return nil
 }
 
 // Form Event Processor Implementation
 
-// ProcessEvent processes a form-related event
-func (fep *FormEventProcessor) ProcessEvent(ctx context.Context, event *events.CDCEvent) error {
+// serviceSettings are the settings of processors calling a service
+type serviceSettings struct {
+	ServiceURL string `mapstructure:"service_url"`
+}
+
+// newFormEventProcessor creates a form processor, handling the form CDC and
+// application events by default
+func newFormEventProcessor(name string, cfg config.ProcessorConfig, deps Dependencies) (Processor, error) {
+	settings := serviceSettings{ServiceURL: "http://form-service:8080"}
+	if err := decodeSettings(cfg.Settings, &settings); err != nil {
+		return nil, err
+	}
+	return &FormEventProcessor{
+		name:        name,
+		topics:      topicsOr(cfg, "cdc.forms", "app.form.created", "app.form.updated"),
+		logger:      deps.Logger,
+		publisher:   deps.Publisher,
+		formService: settings.ServiceURL,
+		metrics:     deps.Metrics,
+	}, nil
+}
+
+// Process processes a form-related event
+func (fep *FormEventProcessor) Process(ctx context.Context, event *events.CDCEvent) error {
 	// Process form-specific logic
 	if event.Source.Table != "forms" && !strings.Contains(event.Source.Topic, "form") {
 		return nil // Skip non-form events
@@ -669,29 +1014,56 @@ func (fep *FormEventProcessor) publishEvent(ctx context.Context, topic string, d
 		},
 	}
 
-	return fep.kafka.PublishMessage(ctx, message)
+	return fep.publisher.PublishMessage(ctx, message)
 }
 
-// GetName returns the processor name
-func (fep *FormEventProcessor) GetName() string {
+// Name returns the processor name
+func (fep *FormEventProcessor) Name() string {
 	return fep.name
 }
 
-// GetType returns the processor type
-func (fep *FormEventProcessor) GetType() string {
-	return "form"
+// Topics returns the topics the processor handles
+func (fep *FormEventProcessor) Topics() []string {
+	return fep.topics
+}
+
+// Start starts the processor
+func (fep *FormEventProcessor) Start(ctx context.Context) error {
+	return nil
 }
 
-// HealthCheck performs a health check
-func (fep *FormEventProcessor) HealthCheck() error {
+// Stop stops the processor
+func (fep *FormEventProcessor) Stop(ctx context.Context) error {
+	return nil
+}
+
+// Health performs a health check
+func (fep *FormEventProcessor) Health() error {
 	// Check connectivity to form service
 	return nil
 }
 
 // Response Event Processor Implementation
 
-// ProcessEvent processes a response-related event
-func (rep *ResponseEventProcessor) ProcessEvent(ctx context.Context, event *events.CDCEvent) error {
+// newResponseEventProcessor creates a response processor, handling the
+// response CDC and application events by default
+func newResponseEventProcessor(name string, cfg config.ProcessorConfig, deps Dependencies) (Processor, error) {
+	settings := serviceSettings{ServiceURL: "http://response-service:8080"}
+	if err := decodeSettings(cfg.Settings, &settings); err != nil {
+		return nil, err
+	}
+	return &ResponseEventProcessor{
+		name:            name,
+		topics:          topicsOr(cfg, "cdc.responses", "app.response.submitted"),
+		logger:          deps.Logger,
+		publisher:       deps.Publisher,
+		responseService: settings.ServiceURL,
+		metrics:         deps.Metrics,
+	}, nil
+}
+
+// Process processes a response-related event
+func (rep *ResponseEventProcessor) Process(ctx context.Context, event *events.CDCEvent) error {
 	// Process response-specific logic
 	if event.Source.Table != "responses" && !strings.Contains(event.Source.Topic, "response") {
 		return nil // Skip non-response events
@@ -703,56 +1075,113 @@ func (rep *ResponseEventProcessor) ProcessEvent(ctx context.Context, event *even
 	return nil
 }
 
-// GetName returns the processor name
-func (rep *ResponseEventProcessor) GetName() string {
+// Name returns the processor name
+func (rep *ResponseEventProcessor) Name() string {
 	return rep.name
 }
 
-// GetType returns the processor type
-func (rep *ResponseEventProcessor) GetType() string {
-	return "response"
+// Topics returns the topics the processor handles
+func (rep *ResponseEventProcessor) Topics() []string {
+	return rep.topics
+}
+
+// Start starts the processor
+func (rep *ResponseEventProcessor) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop stops the processor
+func (rep *ResponseEventProcessor) Stop(ctx context.Context) error {
+	return nil
 }
 
-// HealthCheck performs a health check
-func (rep *ResponseEventProcessor) HealthCheck() error {
+// Health performs a health check
+func (rep *ResponseEventProcessor) Health() error {
 	return nil
 }
 
 // Analytics Event Processor Implementation
 
-// initialize initializes the analytics event processor
-func (aep *AnalyticsEventProcessor) initialize() error {
-	// Initialize aggregators for different event types
-	aep.aggregators["forms"] = &EventAggregator{
-		WindowSize: 5 * time.Minute,
-		Events:     make([]events.CDCEvent, 0),
-		LastFlush:  time.Now(),
-	}
+// analyticsSettings are the settings of analytics processors
+type analyticsSettings struct {
+	ServiceURL string `mapstructure:"service_url"`
+	// Windows are the aggregation windows by table; others use DefaultWindow
+	Windows       map[string]time.Duration `mapstructure:"windows"`
+	DefaultWindow time.Duration            `mapstructure:"default_window"`
+}
+
+// newAnalyticsEventProcessor creates an analytics processor, handling the
+// CDC and application events by default
+func newAnalyticsEventProcessor(name string, cfg config.ProcessorConfig, deps Dependencies) (Processor, error) {
+	settings := analyticsSettings{
+		ServiceURL:    "http://analytics-service:8080",
+		Windows:       map[string]time.Duration{"forms": 5 * time.Minute, "responses": time.Minute},
+		DefaultWindow: 5 * time.Minute,
+	}
+	if err := decodeSettings(cfg.Settings, &settings); err != nil {
+		return nil, err
+	}
+	return &AnalyticsEventProcessor{
+		name:             name,
+		topics:           topicsOr(cfg, "cdc.*", "app.form.created", "app.form.updated", "app.response.submitted", "app.user.registered"),
+		logger:           deps.Logger,
+		publisher:        deps.Publisher,
+		analyticsService: settings.ServiceURL,
+		windows:          settings.Windows,
+		defaultWindow:    settings.DefaultWindow,
+		metrics:          deps.Metrics,
+	}, nil
+}
+
+// Start starts the processor with empty aggregators
+func (aep *AnalyticsEventProcessor) Start(ctx context.Context) error {
+	aep.mutex.Lock()
+	defer aep.mutex.Unlock()
+	aep.aggregators = make(map[string]*EventAggregator)
+	return nil
+}
 
-	aep.aggregators["responses"] = &EventAggregator{
-		WindowSize: 1 * time.Minute,
-		Events:     make([]events.CDCEvent, 0),
-		LastFlush:  time.Now(),
-	}
+// Stop flushes the events aggregated so far
+func (aep *AnalyticsEventProcessor) Stop(ctx context.Context) error {
+	aep.mutex.Lock()
+	aggregators := aep.aggregators
+	aep.aggregators = nil
+	aep.mutex.Unlock()
 
+	for key, aggregator := range aggregators {
+		aggregator.mutex.Lock()
+		pending := aggregator.Events
+		aggregator.Events = nil
+		aggregator.mutex.Unlock()
+		aep.flushAggregatedEvents(ctx, key, pending)
+	}
 	return nil
 }
 
-// ProcessEvent processes an analytics event
-func (aep *AnalyticsEventProcessor) ProcessEvent(ctx context.Context, event *events.CDCEvent) error {
+// Process processes an analytics event
+func (aep *AnalyticsEventProcessor) Process(ctx context.Context, event *events.CDCEvent) error {
 	// Determine aggregator based on event
 	aggregatorKey := aep.getAggregatorKey(event)
 
+	aep.mutex.Lock()
 	aggregator, exists := aep.aggregators[aggregatorKey]
 	if !exists {
 		// Create new aggregator if needed
+		window, ok := aep.windows[aggregatorKey]
+		if !ok {
+			window = aep.defaultWindow
+		}
 		aggregator = &EventAggregator{
-			WindowSize: 5 * time.Minute,
+			WindowSize: window,
 			Events:     make([]events.CDCEvent, 0),
 			LastFlush:  time.Now(),
 		}
+		if aep.aggregators == nil {
+			aep.aggregators = make(map[string]*EventAggregator)
+		}
 		aep.aggregators[aggregatorKey] = aggregator
 	}
+	aep.mutex.Unlock()
 
 	// Add event to aggregator
 	aggregator.mutex.Lock()
@@ -827,7 +1256,7 @@ func (aep *AnalyticsEventProcessor) flushAggregatedEvents(ctx context.Context, k
 		},
 	}
 
-	if err := aep.kafka.PublishMessage(ctx, message); err != nil {
+	if err := aep.publisher.PublishMessage(ctx, message); err != nil {
 		aep.logger.Error("Failed to publish analytics summary",
 			zap.String("topic", topic),
 			zap.Error(err))
@@ -854,18 +1283,18 @@ func (aep *AnalyticsEventProcessor) summarizeTables(events []events.CDCEvent) ma
 	return tables
 }
 
-// GetName returns the processor name
-func (aep *AnalyticsEventProcessor) GetName() string {
+// Name returns the processor name
+func (aep *AnalyticsEventProcessor) Name() string {
 	return aep.name
 }
 
-// GetType returns the processor type
-func (aep *AnalyticsEventProcessor) GetType() string {
-	return "analytics"
+// Topics returns the topics the processor handles
+func (aep *AnalyticsEventProcessor) Topics() []string {
+	return aep.topics
 }
 
-// HealthCheck performs a health check
-func (aep *AnalyticsEventProcessor) HealthCheck() error {
+// Health performs a health check
+func (aep *AnalyticsEventProcessor) Health() error {
 	return nil
 }
 
@@ -1017,42 +1446,49 @@ func (r *TopicRoute) GetName() string {
 	return "topic-route"
 }
 
-// Helper function to initialize processor metrics
-func initProcessorMetrics() *ProcessorMetrics {
-	return &ProcessorMetrics{
-		EventsProcessed: promauto.NewCounter(prometheus.CounterOpts{
+// NewMetrics creates the processor metrics and registers them with reg
+func NewMetrics(reg prometheus.Registerer) *ProcessorMetrics {
+	m := &ProcessorMetrics{
+		EventsProcessed: prometheus.NewCounter(prometheus.CounterOpts{
 			Name: "eventbus_events_processed_total",
 			Help: "Total number of events processed",
 		}),
-		EventsFiltered: promauto.NewCounter(prometheus.CounterOpts{
+		EventsFiltered: prometheus.NewCounter(prometheus.CounterOpts{
 			Name: "eventbus_events_filtered_total",
 			Help: "Total number of events filtered out",
 		}),
-		EventsFailed: promauto.NewCounter(prometheus.CounterOpts{
+		EventsFailed: prometheus.NewCounter(prometheus.CounterOpts{
 			Name: "eventbus_events_failed_total",
 			Help: "Total number of events that failed processing",
 		}),
-		ProcessingLatency: promauto.NewHistogram(prometheus.HistogramOpts{
+		ProcessingLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
 			Name:    "eventbus_processing_latency_seconds",
 			Help:    "Histogram of event processing latencies",
 			Buckets: prometheus.DefBuckets,
 		}),
-		ProcessorHealthScore: promauto.NewGaugeVec(prometheus.GaugeOpts{
+		ProcessorHealthScore: prometheus.NewGaugeVec(prometheus.GaugeOpts{
 			Name: "eventbus_processor_health_score",
 			Help: "Health score of event processors",
 		}, []string{"processor", "type"}),
-		TransformationTime: promauto.NewHistogram(prometheus.HistogramOpts{
+		TransformationTime: prometheus.NewHistogram(prometheus.HistogramOpts{
 			Name:    "eventbus_transformation_time_seconds",
 			Help:    "Time spent in event transformations",
 			Buckets: prometheus.DefBuckets,
 		}),
-		RoutingDecisions: promauto.NewCounterVec(prometheus.CounterOpts{
+		RoutingDecisions: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Name: "eventbus_routing_decisions_total",
 			Help: "Total number of routing decisions made",
 		}, []string{"route", "target"}),
-		ErrorsByType: promauto.NewCounterVec(prometheus.CounterOpts{
+		ErrorsByType: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Name: "eventbus_errors_by_type_total",
 			Help: "Total number of errors by type",
 		}, []string{"processor_type", "error_type"}),
-	}
+		Panics: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "eventbus_processor_panics_total",
+			Help: "Total number of panics recovered from event processors",
+		}, []string{"processor"}),
+	}
+	reg.MustRegister(m.EventsProcessed, m.EventsFiltered, m.EventsFailed, m.ProcessingLatency,
+		m.ProcessorHealthScore, m.TransformationTime, m.RoutingDecisions, m.ErrorsByType, m.Panics)
+	return m
 }
@@ -0,0 +1,377 @@
+package processors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+	"github.com/Mir00r/X-Form-Backend/shared/errreport"
+)
+
+func init() {
+	Register("test", newTestProcessor)
+}
+
+// testProcessor records the events it processes. Its settings make it fail
+// to start or panic on events.
+type testProcessor struct {
+	name     string
+	topics   []string
+	settings testSettings
+
+	mu     sync.Mutex
+	events []string
+	stops  int
+}
+
+type testSettings struct {
+	FailStart bool `mapstructure:"fail_start"`
+	Panic     bool `mapstructure:"panic"`
+}
+
+func newTestProcessor(name string, cfg config.ProcessorConfig, deps Dependencies) (Processor, error) {
+	p := &testProcessor{name: name, topics: topicsOr(cfg, "app.*")}
+	if err := decodeSettings(cfg.Settings, &p.settings); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *testProcessor) Name() string     { return p.name }
+func (p *testProcessor) Topics() []string { return p.topics }
+func (p *testProcessor) Health() error    { return nil }
+
+func (p *testProcessor) Start(context.Context) error {
+	if p.settings.FailStart {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func (p *testProcessor) Process(_ context.Context, event *events.CDCEvent) error {
+	if p.settings.Panic {
+		panic("nil map")
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event.ID)
+	return nil
+}
+
+func (p *testProcessor) Stop(context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stops++
+	return nil
+}
+
+func newTestManager(t *testing.T, processors map[string]config.ProcessorConfig) *ProcessorManager {
+	t.Helper()
+	cfg := &config.Config{EventProcessing: config.EventProcessingConfig{Workers: 1, Processors: processors}}
+	manager, err := NewProcessorManager(cfg, nil, nil, NewMetrics(prometheus.NewRegistry()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return manager
+}
+
+func testEvent(id, topic string) *events.CDCEvent {
+	return &events.CDCEvent{ID: id, Operation: "c", Source: &events.Source{Topic: topic}}
+}
+
+func (pm *ProcessorManager) testProcessor(t *testing.T, name string) *testProcessor {
+	t.Helper()
+	mp, err := pm.get(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mp.processor.(*testProcessor)
+}
+
+func TestManagerLifecycle(t *testing.T) {
+	manager := newTestManager(t, map[string]config.ProcessorConfig{
+		"forms":    {Type: "test", Enabled: true, Topics: []string{"app.form.created"}},
+		"all":      {Type: "test", Enabled: true},
+		"disabled": {Type: "test"},
+		"broken":   {Type: "test", Enabled: true, Settings: map[string]interface{}{"fail_start": true}},
+	})
+	if err := manager.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer manager.Stop()
+
+	states := make(map[string]string)
+	for _, status := range manager.Statuses() {
+		states[status.Name] = status.State
+	}
+	want := map[string]string{"all": StateRunning, "broken": StateFailed, "disabled": StateStopped, "forms": StateRunning}
+	for name, state := range want {
+		if states[name] != state {
+			t.Errorf("%s is %s, want %s", name, states[name], state)
+		}
+	}
+	if status, _ := manager.Status("broken"); !strings.Contains(status.LastError, "connection refused") {
+		t.Errorf("broken: last error %q, want the start error", status.LastError)
+	}
+
+	// Events go to the running processors whose topics match
+	result, err := manager.ProcessEvent(context.Background(), testEvent("e-1", "app.form.created"))
+	if err != nil || strings.Join(result.RoutedTo, ",") != "all,forms" {
+		t.Fatalf("routed to %v (%v), want all and forms", result.RoutedTo, err)
+	}
+	manager.ProcessEvent(context.Background(), testEvent("e-2", "app.user.registered"))
+
+	// A stopped processor receives no more events
+	if err := manager.StopProcessor(context.Background(), "all"); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.StopProcessor(context.Background(), "all"); !errors.Is(err, ErrProcessorNotRunning) {
+		t.Errorf("stopping a stopped processor: err = %v, want ErrProcessorNotRunning", err)
+	}
+	manager.ProcessEvent(context.Background(), testEvent("e-3", "app.form.created"))
+	if all := manager.testProcessor(t, "all"); strings.Join(all.events, ",") != "e-1,e-2" || all.stops != 1 {
+		t.Errorf("all processed %v and stopped %d times, want e-1 and e-2 then stopped once", all.events, all.stops)
+	}
+	if forms := manager.testProcessor(t, "forms"); strings.Join(forms.events, ",") != "e-1,e-3" {
+		t.Errorf("forms processed %v, want e-1 and e-3", forms.events)
+	}
+
+	// Processors are started at runtime, disabled ones included
+	if err := manager.StartProcessor(context.Background(), "disabled"); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.StartProcessor(context.Background(), "disabled"); !errors.Is(err, ErrProcessorRunning) {
+		t.Errorf("starting a running processor: err = %v, want ErrProcessorRunning", err)
+	}
+	if err := manager.StartProcessor(context.Background(), "missing"); !errors.Is(err, ErrProcessorNotFound) {
+		t.Errorf("unknown processor: err = %v, want ErrProcessorNotFound", err)
+	}
+	if status, _ := manager.Status("forms"); status.EventsProcessed != 2 || !status.Healthy || status.StartedAt == nil {
+		t.Errorf("forms = %+v, want 2 events processed and healthy", status)
+	}
+}
+
+func TestManagerIsolatesPanics(t *testing.T) {
+	manager := newTestManager(t, map[string]config.ProcessorConfig{
+		"panicky": {Type: "test", Enabled: true, Settings: map[string]interface{}{"panic": true}},
+		"steady":  {Type: "test", Enabled: true},
+	})
+	if err := manager.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer manager.Stop()
+
+	for _, id := range []string{"e-1", "e-2"} {
+		result, err := manager.ProcessEvent(context.Background(), testEvent(id, "app.form.created"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Success || result.ProcessorName != "panicky" || strings.Join(result.RoutedTo, ",") != "steady" {
+			t.Errorf("result = %+v, want panicky failed and steady processed", result)
+		}
+	}
+
+	if steady := manager.testProcessor(t, "steady"); len(steady.events) != 2 {
+		t.Errorf("steady processed %v, want both events", steady.events)
+	}
+	status, _ := manager.Status("panicky")
+	if status.State != StateRunning || status.Panics != 2 || status.EventsFailed != 2 || !strings.Contains(status.LastError, "nil map") {
+		t.Errorf("panicky = %+v, want running with 2 panics", status)
+	}
+	if panics := testutil.ToFloat64(manager.metrics.Panics.WithLabelValues("panicky")); panics != 2 {
+		t.Errorf("panics metric = %v, want 2", panics)
+	}
+}
+
+func TestManagerReportsErrors(t *testing.T) {
+	manager := newTestManager(t, map[string]config.ProcessorConfig{
+		"panicky": {Type: "test", Enabled: true, Settings: map[string]interface{}{"panic": true}},
+	})
+	reporter := errreport.NewNoop()
+	manager.SetErrorReporter(reporter)
+	if err := manager.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer manager.Stop()
+
+	ctx := errreport.WithMessage(context.Background(), &errreport.Message{
+		ID: "m-1", Topic: "app.form.created", Partition: 2, Offset: 41, Group: "event-bus",
+	})
+	event := testEvent("e-1", "app.form.created")
+	event.Source.Table = "forms"
+	if _, err := manager.ProcessEvent(ctx, event); err != nil {
+		t.Fatal(err)
+	}
+
+	reported := reporter.Events()
+	if len(reported) != 1 {
+		t.Fatalf("reported %d events, want 1", len(reported))
+	}
+	report := reported[0]
+	if !report.Panic || report.Stack == "" || report.Tags["processor"] != "panicky" || report.Err.Error() != "panic: nil map" {
+		t.Errorf("event = %+v, want the panic of panicky", report)
+	}
+	if m := report.Message; m == nil || m.ID != "e-1" || m.EventType != "forms.c" || m.Topic != "app.form.created" ||
+		m.Partition != 2 || m.Offset != 41 || m.Group != "event-bus" {
+		t.Errorf("message = %+v, want the event at the offset consumed", report.Message)
+	}
+}
+
+func TestNewProcessorManagerErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		processors map[string]config.ProcessorConfig
+		want       string
+	}{
+		{"unknown type", map[string]config.ProcessorConfig{"geo": {Enabled: true}}, `unknown type "geo"`},
+		{"unknown setting", map[string]config.ProcessorConfig{"cdc": {Settings: map[string]interface{}{"allowed_table": []string{"forms"}}}}, "allowed_table"},
+		{"invalid setting", map[string]config.ProcessorConfig{"analytics": {Settings: map[string]interface{}{"default_window": "soon"}}}, "default_window"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{EventProcessing: config.EventProcessingConfig{Processors: tt.processors}}
+			_, err := NewProcessorManager(cfg, nil, nil, NewMetrics(prometheus.NewRegistry()))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to mention %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestShippedProcessors(t *testing.T) {
+	// The database credentials come from the deployment environment
+	t.Setenv("DATABASE_NAME", "eventbus")
+	t.Setenv("DATABASE_USERNAME", "eventbus")
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir("../../config"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager, err := NewProcessorManager(cfg, nil, nil, NewMetrics(prometheus.NewRegistry()))
+	if err != nil {
+		t.Fatalf("processors of config/config.yaml: %v", err)
+	}
+
+	// CDC events of forms go to the processors handling them
+	event := testEvent("e-1", "cdc.forms")
+	event.Source.Table = "forms"
+	var names []string
+	for _, mp := range manager.getProcessorsForEvent(event) {
+		names = append(names, mp.processor.Name())
+	}
+	if got := strings.Join(names, ","); got != "analytics-processor,cdc-processor,form-processor" {
+		t.Errorf("cdc.forms is processed by %s", got)
+	}
+}
+
+// pausingConsumer records the partitions paused and resumed
+type pausingConsumer struct {
+	listener kafka.RebalanceListener
+	calls    []string
+}
+
+func (c *pausingConsumer) AddRebalanceListener(listener kafka.RebalanceListener) {
+	c.listener = listener
+}
+
+func (c *pausingConsumer) PausePartitions(groupID string, partitions map[string][]int32) {
+	c.calls = append(c.calls, fmt.Sprintf("pause %s %v", groupID, partitions))
+}
+
+func (c *pausingConsumer) ResumePartitions(groupID string, partitions map[string][]int32) {
+	c.calls = append(c.calls, fmt.Sprintf("resume %s %v", groupID, partitions))
+}
+
+func TestManagerFollowsAssignment(t *testing.T) {
+	manager := newTestManager(t, map[string]config.ProcessorConfig{
+		"forms":     {Type: "test", Enabled: true, Topics: []string{"app.form.created"}},
+		"responses": {Type: "test", Topics: []string{"app.response.*"}},
+	})
+	manager.config.Kafka.Consumer.GroupID = "event-bus"
+	manager.config.Kafka.Consumer.InstanceID = "event-bus-0"
+	consumer := &pausingConsumer{}
+	manager.SetConsumer(consumer)
+	if err := manager.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer manager.Stop()
+
+	// Partitions of topics only stopped processors handle are paused
+	consumer.listener.PartitionsAssigned("event-bus", map[string][]int32{
+		"app.form.created":     {0, 1},
+		"app.response.created": {2},
+		"app.user.registered":  {0},
+	})
+	consumer.listener.PartitionsAssigned("other-group", map[string][]int32{"app.form.created": {3}})
+	if want := []string{"pause event-bus map[app.response.created:[2]]"}; !reflect.DeepEqual(consumer.calls, want) {
+		t.Errorf("assigned: %q, want %q", consumer.calls, want)
+	}
+	assignment, err := manager.Assignment("forms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(assignment.Partitions, map[string][]int32{"app.form.created": {0, 1}}) ||
+		assignment.GroupID != "event-bus" || assignment.InstanceID != "event-bus-0" || assignment.UpdatedAt == nil {
+		t.Errorf("forms assignment = %+v", assignment)
+	}
+
+	consumer.calls = nil
+	if err := manager.StartProcessor(context.Background(), "responses"); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.StopProcessor(context.Background(), "forms"); err != nil {
+		t.Fatal(err)
+	}
+	consumer.listener.PartitionsRevoked("event-bus", map[string][]int32{"app.form.created": {0}})
+	if err := manager.StartProcessor(context.Background(), "forms"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{
+		"resume event-bus map[app.response.created:[2]]",
+		"pause event-bus map[app.form.created:[0 1]]",
+		"resume event-bus map[app.form.created:[1]]",
+	}; !reflect.DeepEqual(consumer.calls, want) {
+		t.Errorf("start and stop: %q, want %q", consumer.calls, want)
+	}
+	if _, err := manager.Assignment("missing"); !errors.Is(err, ErrProcessorNotFound) {
+		t.Errorf("unknown processor: err = %v, want ErrProcessorNotFound", err)
+	}
+}
+
+func TestMatchTopic(t *testing.T) {
+	tests := []struct {
+		pattern, topic string
+		want           bool
+	}{
+		{"cdc.forms", "cdc.forms", true},
+		{"cdc.forms", "cdc.forms.forms", false},
+		{"cdc.*", "cdc.forms.forms", true},
+		{"cdc.*", "cdc", false},
+		{"*", "app.form.created", true},
+	}
+	for _, tt := range tests {
+		if got := matchTopic(tt.pattern, tt.topic); got != tt.want {
+			t.Errorf("matchTopic(%q, %q) = %v, want %v", tt.pattern, tt.topic, got, tt.want)
+		}
+	}
+}
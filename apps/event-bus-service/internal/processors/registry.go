@@ -0,0 +1,81 @@
+package processors
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/mitchellh/mapstructure"
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+)
+
+// Dependencies are the dependencies the manager gives to the processors it
+// creates
+type Dependencies struct {
+	Logger    *zap.Logger
+	Publisher Publisher
+	Metrics   *ProcessorMetrics
+}
+
+// Factory creates a processor named name from its configuration
+type Factory func(name string, cfg config.ProcessorConfig, deps Dependencies) (Processor, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+func init() {
+	Register("cdc", newCDCEventProcessor)
+	Register("form", newFormEventProcessor)
+	Register("response", newResponseEventProcessor)
+	Register("analytics", newAnalyticsEventProcessor)
+}
+
+// Register makes a processor type available to event_processing.processors.
+// It is meant to be called from init functions, and panics when the type is
+// already registered.
+func Register(typ string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, exists := registry[typ]; exists {
+		panic(fmt.Sprintf("processors: type %s registered twice", typ))
+	}
+	registry[typ] = factory
+}
+
+// lookup returns the factory of a processor type
+func lookup(typ string) (Factory, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	factory, ok := registry[typ]
+	return factory, ok
+}
+
+// decodeSettings decodes the settings of a processor into out, which holds
+// the defaults. Durations may be given as strings such as "5m".
+func decodeSettings(settings map[string]interface{}, out interface{}) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+		ErrorUnused:      true,
+		WeaklyTypedInput: true,
+		Result:           out,
+	})
+	if err != nil {
+		return err
+	}
+	if err := decoder.Decode(settings); err != nil {
+		return fmt.Errorf("invalid settings: %w", err)
+	}
+	return nil
+}
+
+// topicsOr returns the topics of the configuration, or defaults when none
+// are configured
+func topicsOr(cfg config.ProcessorConfig, defaults ...string) []string {
+	if len(cfg.Topics) > 0 {
+		return cfg.Topics
+	}
+	return defaults
+}
@@ -0,0 +1,263 @@
+package projections
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+)
+
+// FunnelStageEventType is published by the form service for the first
+// beacon of each stage of a session with a public form
+const FunnelStageEventType = "form.funnel.stage"
+
+// Stages of the funnel of a form. Views and starts are reported by the
+// beacons of the clients, submissions are the responses created.
+const (
+	FunnelStageView   = "view"
+	FunnelStageStart  = "start"
+	FunnelStageSubmit = "submit"
+)
+
+// funnelProjectionName labels the metrics of the funnel projection
+const funnelProjectionName = "form_funnel"
+
+// ErrInvalidFunnelEvent is returned for funnel stage events that can't be
+// projected
+var ErrInvalidFunnelEvent = errors.New("invalid funnel event")
+
+// funnelPruneInterval is how often the sessions past their retention are
+// pruned
+const funnelPruneInterval = time.Hour
+
+// FunnelStage is one stage reached by a session with a form, counted on the
+// UTC day it was reached. The session of a submission is its response.
+type FunnelStage struct {
+	FormID    string
+	SessionID string
+	Stage     string
+	Day       time.Time
+}
+
+// funnelStageEvent is the payload of form.funnel.stage events
+type funnelStageEvent struct {
+	FormID     string    `json:"form_id"`
+	SessionID  string    `json:"session_id"`
+	Stage      string    `json:"stage"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// ParseFunnelStageEvent decodes and validates the payload of a
+// form.funnel.stage event
+func ParseFunnelStageEvent(message *kafka.Message) (*FunnelStage, error) {
+	encoded, err := json.Marshal(message.Data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFunnelEvent, err)
+	}
+	var event funnelStageEvent
+	if err := json.Unmarshal(encoded, &event); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFunnelEvent, err)
+	}
+	switch {
+	case event.FormID == "":
+		return nil, fmt.Errorf("%w: form_id is required", ErrInvalidFunnelEvent)
+	case event.SessionID == "":
+		return nil, fmt.Errorf("%w: session_id is required", ErrInvalidFunnelEvent)
+	case event.Stage != FunnelStageView && event.Stage != FunnelStageStart:
+		return nil, fmt.Errorf("%w: unknown stage %q", ErrInvalidFunnelEvent, event.Stage)
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = message.Metadata.Timestamp
+	}
+	return &FunnelStage{
+		FormID:    event.FormID,
+		SessionID: event.SessionID,
+		Stage:     event.Stage,
+		Day:       funnelDay(event.OccurredAt),
+	}, nil
+}
+
+// SubmissionStage returns the submit stage of a created response, nil for
+// test submissions, which funnels never count
+func (e *ResponseEvent) SubmissionStage() *FunnelStage {
+	if e.Test {
+		return nil
+	}
+	return &FunnelStage{
+		FormID:    e.FormID,
+		SessionID: "response:" + e.ResponseID,
+		Stage:     FunnelStageSubmit,
+		Day:       funnelDay(e.SubmittedAt),
+	}
+}
+
+// DedupFunnelStages keeps the first of the stages of a session reached more
+// than once in a batch, as when an event is delivered twice
+func DedupFunnelStages(stages []FunnelStage) []FunnelStage {
+	seen := make(map[[3]string]bool, len(stages))
+	deduped := stages[:0:0]
+	for _, stage := range stages {
+		key := [3]string{stage.FormID, stage.SessionID, stage.Stage}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		deduped = append(deduped, stage)
+	}
+	return deduped
+}
+
+// funnelDay returns the UTC day of t
+func funnelDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// FunnelStore persists the stages of sessions and the daily funnels of
+// forms
+type FunnelStore interface {
+	// InsertFunnelStages writes the stages not counted yet, keyed on the
+	// form, the session and the stage, adds them to the daily counts of
+	// their forms and returns the number counted
+	InsertFunnelStages(ctx context.Context, stages []FunnelStage) (int64, error)
+	// PruneFunnelSessions deletes the stages of sessions reached before a
+	// day, keeping the daily counts, and returns the number deleted
+	PruneFunnelSessions(ctx context.Context, before time.Time) (int64, error)
+}
+
+// FunnelProjection consumes funnel stage and response created events and
+// counts the sessions viewing, starting and submitting each form per day
+// into the form_funnel_daily table. It implements kafka.BatchConsumerHandler.
+type FunnelProjection struct {
+	store     FunnelStore
+	metrics   *Metrics
+	logger    *zap.Logger
+	topics    []string
+	groupID   string
+	retention time.Duration
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+// NewFunnelProjection creates the funnel projection writing to store
+func NewFunnelProjection(cfg config.FunnelProjectionConfig, store FunnelStore, metrics *Metrics, logger *zap.Logger) *FunnelProjection {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	return &FunnelProjection{
+		store:     store,
+		metrics:   metrics,
+		logger:    logger,
+		topics:    cfg.Topics,
+		groupID:   cfg.GroupID,
+		retention: cfg.SessionRetention,
+		now:       time.Now,
+	}
+}
+
+// GetTopics returns the topics the projection consumes
+func (p *FunnelProjection) GetTopics() []string {
+	return p.topics
+}
+
+// GetGroupID returns the consumer group the projection commits offsets in
+func (p *FunnelProjection) GetGroupID() string {
+	return p.groupID
+}
+
+// HandleBatch counts the stages of a batch in a single write: the beacon
+// stages of form.funnel.stage events and the submissions of
+// form.response.created events, test submissions aside. Stages are counted
+// once per session, so redeliveries never double-count. Messages of other
+// event types are skipped and malformed events are logged and dropped; a
+// failed write fails the whole batch so it is redelivered.
+func (p *FunnelProjection) HandleBatch(ctx context.Context, messages []*kafka.Message) error {
+	start := time.Now()
+
+	var (
+		stages    []FunnelStage
+		projected int
+		newest    time.Time
+	)
+	for _, message := range messages {
+		var stage *FunnelStage
+		var err error
+		switch message.EventType {
+		case FunnelStageEventType:
+			stage, err = ParseFunnelStageEvent(message)
+		case ResponseCreatedEventType:
+			var event *ResponseEvent
+			if event, err = ParseResponseEvent(message); err == nil {
+				stage = event.SubmissionStage()
+			}
+		default:
+			p.metrics.Events.WithLabelValues(funnelProjectionName, "skipped").Inc()
+			continue
+		}
+		if err != nil {
+			p.logger.Warn("Dropping unprojectable funnel event",
+				zap.String("event_id", message.ID),
+				zap.String("topic", message.Topic),
+				zap.Error(err))
+			p.metrics.Events.WithLabelValues(funnelProjectionName, "invalid").Inc()
+			continue
+		}
+		if stage == nil {
+			p.metrics.Events.WithLabelValues(funnelProjectionName, "skipped").Inc()
+			continue
+		}
+
+		stages = append(stages, *stage)
+		projected++
+		if message.Metadata.Timestamp.After(newest) {
+			newest = message.Metadata.Timestamp
+		}
+	}
+
+	stages = DedupFunnelStages(stages)
+	counted, err := p.store.InsertFunnelStages(ctx, stages)
+	if err != nil {
+		p.metrics.Events.WithLabelValues(funnelProjectionName, "failed").Add(float64(projected))
+		return fmt.Errorf("failed to write funnel projection: %w", err)
+	}
+	p.prune(ctx)
+
+	p.metrics.Events.WithLabelValues(funnelProjectionName, "projected").Add(float64(projected))
+	p.metrics.RowsWritten.WithLabelValues(funnelProjectionName).Add(float64(counted))
+	p.metrics.DuplicateRows.WithLabelValues(funnelProjectionName).Add(float64(int64(projected) - counted))
+	p.metrics.BatchDuration.WithLabelValues(funnelProjectionName).Observe(time.Since(start).Seconds())
+	if !newest.IsZero() {
+		p.metrics.Lag.WithLabelValues(funnelProjectionName).Set(time.Since(newest).Seconds())
+	}
+
+	p.logger.Debug("Projected funnel events",
+		zap.Int("messages", len(messages)),
+		zap.Int("events", projected),
+		zap.Int64("counted", counted))
+
+	return nil
+}
+
+// prune deletes the sessions past their retention at most once per
+// funnelPruneInterval. Stages are only remembered to skip redeliveries, so
+// a failed prune is logged and retried later.
+func (p *FunnelProjection) prune(ctx context.Context) {
+	now := p.now()
+	if p.retention <= 0 || now.Sub(p.lastPrune) < funnelPruneInterval {
+		return
+	}
+	p.lastPrune = now
+
+	pruned, err := p.store.PruneFunnelSessions(ctx, funnelDay(now.Add(-p.retention)))
+	if err != nil {
+		p.logger.Warn("Failed to prune funnel sessions", zap.Error(err))
+		return
+	}
+	p.logger.Debug("Pruned funnel sessions", zap.Int64("pruned", pruned))
+}
@@ -0,0 +1,234 @@
+package projections
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+)
+
+// memoryFunnelStore mirrors the form_funnel_sessions primary key and the
+// form_funnel_daily counts in memory
+type memoryFunnelStore struct {
+	mu       sync.Mutex
+	sessions map[[3]string]time.Time
+	daily    map[string]map[string]int64
+	pruned   []time.Time
+}
+
+func newMemoryFunnelStore() *memoryFunnelStore {
+	return &memoryFunnelStore{sessions: make(map[[3]string]time.Time), daily: make(map[string]map[string]int64)}
+}
+
+func (s *memoryFunnelStore) InsertFunnelStages(_ context.Context, stages []FunnelStage) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var counted int64
+	for _, stage := range stages {
+		key := [3]string{stage.FormID, stage.SessionID, stage.Stage}
+		if _, ok := s.sessions[key]; ok {
+			continue
+		}
+		s.sessions[key] = stage.Day
+		day := stage.FormID + "/" + stage.Day.Format("2006-01-02")
+		if s.daily[day] == nil {
+			s.daily[day] = make(map[string]int64)
+		}
+		s.daily[day][stage.Stage]++
+		counted++
+	}
+	return counted, nil
+}
+
+func (s *memoryFunnelStore) PruneFunnelSessions(_ context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruned = append(s.pruned, before)
+	var pruned int64
+	for key, day := range s.sessions {
+		if day.Before(before) {
+			delete(s.sessions, key)
+			pruned++
+		}
+	}
+	return pruned, nil
+}
+
+func funnelMessage(id, session, stage string, at time.Time) *kafka.Message {
+	return &kafka.Message{
+		ID:        id,
+		EventType: FunnelStageEventType,
+		Topic:     "app.form.funnel.stage",
+		Data: map[string]interface{}{
+			"form_id":     "form-1",
+			"session_id":  session,
+			"stage":       stage,
+			"occurred_at": at.Format(time.RFC3339),
+		},
+		Metadata: kafka.MessageMetadata{Timestamp: at},
+	}
+}
+
+func submissionMessage(id, responseID string, test bool, at time.Time) *kafka.Message {
+	return &kafka.Message{
+		ID:        id,
+		EventType: ResponseCreatedEventType,
+		Topic:     "app.form.response.created",
+		Data: map[string]interface{}{
+			"response_id":  responseID,
+			"form_id":      "form-1",
+			"answers_hash": "sha256:" + responseID,
+			"answers":      map[string]interface{}{"q1": "yes"},
+			"submitted_at": at.Format(time.RFC3339),
+			"is_test":      test,
+		},
+		Metadata: kafka.MessageMetadata{Timestamp: at},
+	}
+}
+
+func TestFunnelProjectionCountsSessionsOnce(t *testing.T) {
+	store := newMemoryFunnelStore()
+	metrics := NewMetrics(prometheus.NewRegistry())
+	projection := NewFunnelProjection(config.FunnelProjectionConfig{Topics: []string{"t"}, GroupID: "g"}, store, metrics, nil)
+
+	evening := time.Date(2026, 10, 15, 23, 50, 0, 0, time.UTC)
+	morning := evening.Add(20 * time.Minute)
+	first := []*kafka.Message{
+		funnelMessage("e-1", "s1", FunnelStageView, evening),
+		// The same beacon published twice
+		funnelMessage("e-2", "s1", FunnelStageView, evening),
+		funnelMessage("e-3", "s2", FunnelStageView, evening),
+		// Started after midnight, counted the day after
+		funnelMessage("e-4", "s1", FunnelStageStart, morning),
+		submissionMessage("e-5", "r1", false, morning),
+		submissionMessage("e-6", "r2", true, morning),
+		{ID: "e-7", EventType: FunnelStageEventType, Data: map[string]interface{}{"form_id": "form-1", "session_id": "s3", "stage": "submit"}},
+		{ID: "e-8", EventType: "form.published", Data: map[string]interface{}{}},
+	}
+	if err := projection.HandleBatch(context.Background(), first); err != nil {
+		t.Fatal(err)
+	}
+	// A redelivery of the batch counts nothing
+	if err := projection.HandleBatch(context.Background(), first[:6]); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]map[string]int64{
+		"form-1/2026-10-15": {FunnelStageView: 2},
+		"form-1/2026-10-16": {FunnelStageStart: 1, FunnelStageSubmit: 1},
+	}
+	if fmt.Sprint(store.daily) != fmt.Sprint(want) {
+		t.Errorf("daily counts = %v, want %v", store.daily, want)
+	}
+	if got := testutil.ToFloat64(metrics.RowsWritten.WithLabelValues(funnelProjectionName)); got != 4 {
+		t.Errorf("stages counted = %v, want 4", got)
+	}
+	if got := testutil.ToFloat64(metrics.Events.WithLabelValues(funnelProjectionName, "invalid")); got != 1 {
+		t.Errorf("invalid events = %v, want the unknown stage", got)
+	}
+	// The test submission and the other event type
+	if got := testutil.ToFloat64(metrics.Events.WithLabelValues(funnelProjectionName, "skipped")); got != 3 {
+		t.Errorf("skipped events = %v, want 3", got)
+	}
+}
+
+func TestDedupFunnelStages(t *testing.T) {
+	day := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	stages := DedupFunnelStages([]FunnelStage{
+		{FormID: "form-1", SessionID: "s1", Stage: FunnelStageView, Day: day},
+		{FormID: "form-1", SessionID: "s1", Stage: FunnelStageView, Day: day.AddDate(0, 0, 1)},
+		{FormID: "form-1", SessionID: "s1", Stage: FunnelStageStart, Day: day},
+		{FormID: "form-2", SessionID: "s1", Stage: FunnelStageView, Day: day},
+	})
+	if len(stages) != 3 || !stages[0].Day.Equal(day) {
+		t.Errorf("stages = %+v, want the first view of s1 on form-1, its start and its view of form-2", stages)
+	}
+}
+
+func TestFunnelProjectionPrunesSessions(t *testing.T) {
+	store := newMemoryFunnelStore()
+	projection := NewFunnelProjection(config.FunnelProjectionConfig{SessionRetention: 7 * 24 * time.Hour}, store, NewMetrics(prometheus.NewRegistry()), nil)
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	projection.now = func() time.Time { return now }
+
+	batch := []*kafka.Message{funnelMessage("e-1", "old", FunnelStageView, now.AddDate(0, 0, -8)), funnelMessage("e-2", "new", FunnelStageView, now)}
+	if err := projection.HandleBatch(context.Background(), batch); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.pruned) != 1 || !store.pruned[0].Equal(time.Date(2026, 10, 9, 0, 0, 0, 0, time.UTC)) || len(store.sessions) != 1 {
+		t.Fatalf("pruned before %v, %d sessions left, want those before 2026-10-09 pruned", store.pruned, len(store.sessions))
+	}
+
+	// Sessions are pruned at most once per interval
+	now = now.Add(time.Minute)
+	if err := projection.HandleBatch(context.Background(), batch[1:]); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.pruned) != 1 {
+		t.Errorf("pruned %d times within the interval, want once", len(store.pruned))
+	}
+}
+
+// TestFunnelStorePostgres counts stages into a real database when
+// EVENTBUS_TEST_DATABASE_URL is set
+func TestFunnelStorePostgres(t *testing.T) {
+	dsn := os.Getenv("EVENTBUS_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("EVENTBUS_TEST_DATABASE_URL not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if _, err := db.ExecContext(ctx, "DROP TABLE IF EXISTS form_funnel_sessions, form_funnel_daily"); err != nil {
+		t.Fatalf("failed to reset tables: %v", err)
+	}
+	store := NewPostgresStore(db)
+	if err := store.EnsureSchema(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	day := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	stages := []FunnelStage{
+		{FormID: "form-1", SessionID: "s1", Stage: FunnelStageView, Day: day},
+		{FormID: "form-1", SessionID: "s2", Stage: FunnelStageView, Day: day},
+		{FormID: "form-1", SessionID: "s1", Stage: FunnelStageStart, Day: day},
+		{FormID: "form-1", SessionID: "response:r1", Stage: FunnelStageSubmit, Day: day},
+	}
+	for i, want := range []int64{4, 0} {
+		counted, err := store.InsertFunnelStages(ctx, stages)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if counted != want {
+			t.Errorf("insert %d counted %d stages, want %d", i, counted, want)
+		}
+	}
+
+	var views, starts, submissions int64
+	err = db.QueryRowContext(ctx, "SELECT views, starts, submissions FROM form_funnel_daily WHERE form_id = 'form-1' AND day = $1", day).
+		Scan(&views, &starts, &submissions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if views != 2 || starts != 1 || submissions != 1 {
+		t.Errorf("daily counts = %d views, %d starts, %d submissions, want 2, 1 and 1", views, starts, submissions)
+	}
+
+	if pruned, err := store.PruneFunnelSessions(ctx, day.AddDate(0, 0, 1)); err != nil || pruned != 4 {
+		t.Errorf("pruned %d sessions, %v, want 4", pruned, err)
+	}
+}
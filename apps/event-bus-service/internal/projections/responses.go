@@ -0,0 +1,469 @@
+// Package projections builds analytics-friendly read models from events
+// consumed off the event bus. Projections are written idempotently, keyed on
+// the event ID, so replays and consumer restarts never double-count.
+package projections
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+)
+
+// Event types published by the response service for accepted submissions,
+// for edits of a submission and for the responses of a form purged for
+// retention, and by the form service for the batches of bulk deletions
+const (
+	ResponseCreatedEventType  = "form.response.created"
+	ResponseUpdatedEventType  = "form.response.updated"
+	ResponsePurgedEventType   = "form.response.purged"
+	ResponsesDeletedEventType = "form.responses.deleted"
+)
+
+// Purge modes of form.response.purged events
+const (
+	PurgeModeAnonymize = "anonymize"
+	PurgeModeDelete    = "delete"
+)
+
+// responseProjectionName labels the metrics of the response projection
+const responseProjectionName = "response_events"
+
+// ErrInvalidResponseEvent is returned for response events that can't be
+// projected
+var ErrInvalidResponseEvent = errors.New("invalid response event")
+
+// ResponseEvent is the payload of form.response.created and
+// form.response.updated events. Revision starts at 1 and is incremented by
+// every edit of the response.
+type ResponseEvent struct {
+	ResponseID  string                     `json:"response_id"`
+	FormID      string                     `json:"form_id"`
+	FormVersion int                        `json:"form_version"`
+	Revision    int                        `json:"revision"`
+	AnswersHash string                     `json:"answers_hash"`
+	Answers     map[string]json.RawMessage `json:"answers"`
+	// PresentedOrder is the order the options of randomized choice questions
+	// were shown in, as option keys by question ID
+	PresentedOrder map[string][]string `json:"presented_order,omitempty"`
+	Respondent     Respondent          `json:"respondent"`
+	SubmittedAt    time.Time           `json:"submitted_at"`
+	// Test is set for the test submissions of the owners and collaborators
+	// of the form. They are projected flagged, and left out of the counts of
+	// analytics, anomaly detection, live stats and notifications.
+	Test bool `json:"is_test,omitempty"`
+	// Timings are the timing beacons of the client, which only submissions
+	// carry. They are validated by TimingsRow.
+	Timings json.RawMessage `json:"timings,omitempty"`
+	// QuestionKeys are the stable keys of the questions answered, by
+	// question ID. Analytics join answers on them, so that the answers to a
+	// question deleted and recreated under the same key are counted together.
+	QuestionKeys map[string]string `json:"question_keys,omitempty"`
+}
+
+// Respondent is the respondent metadata carried by a response event
+type Respondent struct {
+	ID        string `json:"id,omitempty"`
+	Anonymous bool   `json:"anonymous"`
+	SessionID string `json:"session_id,omitempty"`
+	Source    string `json:"source,omitempty"`
+	// Email is set when the respondent gave an address for a copy of their
+	// answers. It is not projected.
+	Email string `json:"email,omitempty"`
+}
+
+// ResponsePurgeEvent is the payload of form.response.purged events: the
+// responses of a form submitted before PurgedBefore were anonymized or
+// deleted. It carries counts, never the content of the responses.
+type ResponsePurgeEvent struct {
+	FormID       string    `json:"form_id"`
+	Mode         string    `json:"mode"`
+	Count        int       `json:"count"`
+	PurgedBefore time.Time `json:"purged_before"`
+}
+
+// ParseResponsePurgeEvent decodes and validates the payload of a
+// form.response.purged event
+func ParseResponsePurgeEvent(message *kafka.Message) (*ResponsePurgeEvent, error) {
+	encoded, err := json.Marshal(message.Data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponseEvent, err)
+	}
+	var event ResponsePurgeEvent
+	if err := json.Unmarshal(encoded, &event); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponseEvent, err)
+	}
+	switch {
+	case event.FormID == "":
+		return nil, fmt.Errorf("%w: form_id is required", ErrInvalidResponseEvent)
+	case event.Mode != PurgeModeAnonymize && event.Mode != PurgeModeDelete:
+		return nil, fmt.Errorf("%w: unknown mode %q", ErrInvalidResponseEvent, event.Mode)
+	case event.PurgedBefore.IsZero():
+		return nil, fmt.Errorf("%w: purged_before is required", ErrInvalidResponseEvent)
+	}
+	return &event, nil
+}
+
+// ResponseDeletionEvent is the payload of form.responses.deleted events: a
+// batch of the responses of a form deleted by a bulk deletion
+type ResponseDeletionEvent struct {
+	FormID      string   `json:"form_id"`
+	DeletionID  string   `json:"deletion_id"`
+	ResponseIDs []string `json:"response_ids"`
+}
+
+// ParseResponseDeletionEvent decodes and validates the payload of a
+// form.responses.deleted event
+func ParseResponseDeletionEvent(message *kafka.Message) (*ResponseDeletionEvent, error) {
+	encoded, err := json.Marshal(message.Data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponseEvent, err)
+	}
+	var event ResponseDeletionEvent
+	if err := json.Unmarshal(encoded, &event); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponseEvent, err)
+	}
+	switch {
+	case event.FormID == "":
+		return nil, fmt.Errorf("%w: form_id is required", ErrInvalidResponseEvent)
+	case len(event.ResponseIDs) == 0:
+		return nil, fmt.Errorf("%w: response_ids is required", ErrInvalidResponseEvent)
+	}
+	return &event, nil
+}
+
+// AnswerRow is one row of the response_events projection: a single answer
+// of a single revision of a response
+type AnswerRow struct {
+	EventID      string
+	QuestionID   string
+	ResponseID   string
+	FormID       string
+	FormVersion  int
+	Revision     int
+	Answer       json.RawMessage
+	AnswersHash  string
+	RespondentID string
+	IsAnonymous  bool
+	SubmittedAt  time.Time
+	// PresentedOrder is the order the options were shown in, for randomized
+	// choice questions
+	PresentedOrder []string
+	// IsTest flags the rows of test submissions
+	IsTest bool
+	// QuestionKey is the key of the question when the answer was given,
+	// empty for events published before questions had keys
+	QuestionKey string
+}
+
+// ParseResponseEvent decodes and validates the payload of a response event
+func ParseResponseEvent(message *kafka.Message) (*ResponseEvent, error) {
+	if message.ID == "" {
+		return nil, fmt.Errorf("%w: event id is required", ErrInvalidResponseEvent)
+	}
+
+	var payload []byte
+	switch data := message.Data.(type) {
+	case []byte:
+		payload = data
+	case json.RawMessage:
+		payload = data
+	default:
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidResponseEvent, err)
+		}
+		payload = encoded
+	}
+
+	var event ResponseEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponseEvent, err)
+	}
+
+	switch {
+	case event.ResponseID == "":
+		return nil, fmt.Errorf("%w: response_id is required", ErrInvalidResponseEvent)
+	case event.FormID == "":
+		return nil, fmt.Errorf("%w: form_id is required", ErrInvalidResponseEvent)
+	case event.AnswersHash == "":
+		return nil, fmt.Errorf("%w: answers_hash is required", ErrInvalidResponseEvent)
+	}
+	if event.FormVersion == 0 {
+		event.FormVersion = 1
+	}
+	if event.Revision == 0 {
+		event.Revision = 1
+	}
+	if event.SubmittedAt.IsZero() {
+		event.SubmittedAt = message.Metadata.Timestamp
+	}
+
+	return &event, nil
+}
+
+// Rows flattens the event into one row per answered question, ordered by
+// question ID
+func (e *ResponseEvent) Rows(eventID string) []AnswerRow {
+	questionIDs := make([]string, 0, len(e.Answers))
+	for questionID := range e.Answers {
+		questionIDs = append(questionIDs, questionID)
+	}
+	sort.Strings(questionIDs)
+
+	rows := make([]AnswerRow, 0, len(questionIDs))
+	for _, questionID := range questionIDs {
+		rows = append(rows, AnswerRow{
+			EventID:        eventID,
+			QuestionID:     questionID,
+			ResponseID:     e.ResponseID,
+			FormID:         e.FormID,
+			FormVersion:    e.FormVersion,
+			Revision:       e.Revision,
+			Answer:         e.Answers[questionID],
+			AnswersHash:    e.AnswersHash,
+			RespondentID:   e.Respondent.ID,
+			IsAnonymous:    e.Respondent.Anonymous,
+			SubmittedAt:    e.SubmittedAt,
+			PresentedOrder: e.PresentedOrder[questionID],
+			IsTest:         e.Test,
+			QuestionKey:    e.QuestionKeys[questionID],
+		})
+	}
+	return rows
+}
+
+// TimingsRow returns the response_timings row of the event, nil when it
+// carries no timings
+func (e *ResponseEvent) TimingsRow() (*ResponseTimings, error) {
+	if len(e.Timings) == 0 || string(e.Timings) == "null" {
+		return nil, nil
+	}
+	questions, err := ParseTimings(e.Timings)
+	if err != nil {
+		return nil, err
+	}
+	return &ResponseTimings{
+		ResponseID:  e.ResponseID,
+		FormID:      e.FormID,
+		SubmittedAt: e.SubmittedAt,
+		IsTest:      e.Test,
+		Questions:   questions,
+	}, nil
+}
+
+// ResponseProjection consumes response events and writes their answers into
+// the response_events table, replacing the answers of earlier revisions when
+// a response is edited. It implements kafka.BatchConsumerHandler.
+type ResponseProjection struct {
+	store   Store
+	metrics *Metrics
+	logger  *zap.Logger
+	topics  []string
+	groupID string
+}
+
+// NewResponseProjection creates the response projection writing to store
+func NewResponseProjection(cfg config.ResponseProjectionConfig, store Store, metrics *Metrics, logger *zap.Logger) *ResponseProjection {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	return &ResponseProjection{
+		store:   store,
+		metrics: metrics,
+		logger:  logger,
+		topics:  cfg.Topics,
+		groupID: cfg.GroupID,
+	}
+}
+
+// GetTopics returns the topics the projection consumes
+func (p *ResponseProjection) GetTopics() []string {
+	return p.topics
+}
+
+// GetGroupID returns the consumer group the projection commits offsets in
+func (p *ResponseProjection) GetGroupID() string {
+	return p.groupID
+}
+
+// HandleBatch projects a batch of created and updated responses in a single
+// write, with the timings of the submissions, then applies the purges and
+// deletions of the batch. Messages of other event types are skipped and
+// malformed events are logged and dropped so they can't block the partition;
+// malformed timings are dropped alone. A failed write fails the whole batch
+// so it is redelivered.
+func (p *ResponseProjection) HandleBatch(ctx context.Context, messages []*kafka.Message) error {
+	start := time.Now()
+
+	var (
+		rows      []AnswerRow
+		timings   []ResponseTimings
+		purges    []*ResponsePurgeEvent
+		deletions []*ResponseDeletionEvent
+		projected int
+		newest    time.Time
+	)
+	for _, message := range messages {
+		if message.EventType == ResponsePurgedEventType {
+			purge, err := ParseResponsePurgeEvent(message)
+			if err != nil {
+				p.logger.Warn("Dropping unprojectable response purge event",
+					zap.String("event_id", message.ID),
+					zap.String("topic", message.Topic),
+					zap.Error(err))
+				p.metrics.Events.WithLabelValues(responseProjectionName, "invalid").Inc()
+				continue
+			}
+			purges = append(purges, purge)
+			continue
+		}
+		if message.EventType == ResponsesDeletedEventType {
+			deletion, err := ParseResponseDeletionEvent(message)
+			if err != nil {
+				p.logger.Warn("Dropping unprojectable response deletion event",
+					zap.String("event_id", message.ID),
+					zap.String("topic", message.Topic),
+					zap.Error(err))
+				p.metrics.Events.WithLabelValues(responseProjectionName, "invalid").Inc()
+				continue
+			}
+			deletions = append(deletions, deletion)
+			continue
+		}
+		if message.EventType != ResponseCreatedEventType && message.EventType != ResponseUpdatedEventType {
+			p.metrics.Events.WithLabelValues(responseProjectionName, "skipped").Inc()
+			continue
+		}
+
+		event, err := ParseResponseEvent(message)
+		if err != nil {
+			p.logger.Warn("Dropping unprojectable response event",
+				zap.String("event_id", message.ID),
+				zap.String("topic", message.Topic),
+				zap.Error(err))
+			p.metrics.Events.WithLabelValues(responseProjectionName, "invalid").Inc()
+			continue
+		}
+
+		rows = append(rows, event.Rows(message.ID)...)
+		if message.EventType == ResponseCreatedEventType {
+			timing, err := event.TimingsRow()
+			if err != nil {
+				p.logger.Warn("Dropping invalid response timings",
+					zap.String("event_id", message.ID),
+					zap.String("response_id", event.ResponseID),
+					zap.Error(err))
+			} else if timing != nil {
+				timings = append(timings, *timing)
+			}
+		}
+		projected++
+		if message.Metadata.Timestamp.After(newest) {
+			newest = message.Metadata.Timestamp
+		}
+	}
+
+	inserted, err := p.store.InsertAnswerRows(ctx, rows)
+	if err != nil {
+		p.metrics.Events.WithLabelValues(responseProjectionName, "failed").Add(float64(projected))
+		return fmt.Errorf("failed to write response projection: %w", err)
+	}
+	if err := p.store.InsertTimings(ctx, timings); err != nil {
+		p.metrics.Events.WithLabelValues(responseProjectionName, "failed").Add(float64(projected))
+		return fmt.Errorf("failed to write response timings: %w", err)
+	}
+
+	// Purges and deletions are idempotent, so a batch redelivered after one
+	// of them failed applies them all again
+	for _, purge := range purges {
+		purged, err := p.store.PurgeFormResponses(ctx, purge.FormID, purge.PurgedBefore, purge.Mode == PurgeModeAnonymize)
+		if err != nil {
+			p.metrics.Events.WithLabelValues(responseProjectionName, "failed").Add(float64(projected + len(purges) + len(deletions)))
+			return fmt.Errorf("failed to purge responses of form %s: %w", purge.FormID, err)
+		}
+		p.logger.Info("Purged projected responses",
+			zap.String("form_id", purge.FormID),
+			zap.String("mode", purge.Mode),
+			zap.Int("count", purge.Count),
+			zap.Int64("purged", purged))
+	}
+	for _, deletion := range deletions {
+		deleted, err := p.store.DeleteResponses(ctx, deletion.FormID, deletion.ResponseIDs)
+		if err != nil {
+			p.metrics.Events.WithLabelValues(responseProjectionName, "failed").Add(float64(projected + len(purges) + len(deletions)))
+			return fmt.Errorf("failed to delete responses of form %s: %w", deletion.FormID, err)
+		}
+		p.logger.Info("Deleted projected responses",
+			zap.String("form_id", deletion.FormID),
+			zap.String("deletion_id", deletion.DeletionID),
+			zap.Int("count", len(deletion.ResponseIDs)),
+			zap.Int64("deleted", deleted))
+	}
+	projected += len(purges) + len(deletions)
+
+	p.metrics.Events.WithLabelValues(responseProjectionName, "projected").Add(float64(projected))
+	p.metrics.RowsWritten.WithLabelValues(responseProjectionName).Add(float64(inserted))
+	p.metrics.DuplicateRows.WithLabelValues(responseProjectionName).Add(float64(int64(len(rows)) - inserted))
+	p.metrics.BatchDuration.WithLabelValues(responseProjectionName).Observe(time.Since(start).Seconds())
+	if !newest.IsZero() {
+		p.metrics.Lag.WithLabelValues(responseProjectionName).Set(time.Since(newest).Seconds())
+	}
+
+	p.logger.Debug("Projected response events",
+		zap.Int("messages", len(messages)),
+		zap.Int("events", projected),
+		zap.Int("rows", len(rows)),
+		zap.Int64("inserted", inserted))
+
+	return nil
+}
+
+// Metrics contains the Prometheus metrics of the projections, labeled by
+// projection. Throughput is the rate of Events and RowsWritten.
+type Metrics struct {
+	Events        *prometheus.CounterVec
+	RowsWritten   *prometheus.CounterVec
+	DuplicateRows *prometheus.CounterVec
+	BatchDuration *prometheus.HistogramVec
+	Lag           *prometheus.GaugeVec
+}
+
+// NewMetrics creates the projection metrics and registers them with reg
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		Events: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "eventbus_projection_events_total",
+			Help: "Total number of events consumed by projections, by outcome",
+		}, []string{"projection", "status"}),
+		RowsWritten: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "eventbus_projection_rows_written_total",
+			Help: "Total number of projection rows inserted",
+		}, []string{"projection"}),
+		DuplicateRows: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "eventbus_projection_duplicate_rows_total",
+			Help: "Total number of projection rows skipped because their event was already projected",
+		}, []string{"projection"}),
+		BatchDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "eventbus_projection_batch_duration_seconds",
+			Help:    "Duration of projecting one batch of events",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"projection"}),
+		Lag: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "eventbus_projection_lag_seconds",
+			Help: "Age of the newest event in the last projected batch",
+		}, []string{"projection"}),
+	}
+
+	reg.MustRegister(m.Events, m.RowsWritten, m.DuplicateRows, m.BatchDuration, m.Lag)
+	return m
+}
@@ -0,0 +1,658 @@
+package projections
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+)
+
+// memoryStore mirrors the response_events primary key and revision
+// replacement, and the response_timings table, in memory
+type memoryStore struct {
+	mu      sync.Mutex
+	rows    map[string]AnswerRow
+	timings map[string]ResponseTimings
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{rows: make(map[string]AnswerRow), timings: make(map[string]ResponseTimings)}
+}
+
+func (s *memoryStore) InsertTimings(_ context.Context, timings []ResponseTimings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, timing := range timings {
+		if _, ok := s.timings[timing.ResponseID]; !ok {
+			s.timings[timing.ResponseID] = timing
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) InsertAnswerRows(_ context.Context, rows []AnswerRow) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := make(map[string]int)
+	for _, row := range s.rows {
+		if row.Revision > stored[row.ResponseID] {
+			stored[row.ResponseID] = row.Revision
+		}
+	}
+	rows, superseded := LatestRevisionRows(rows, stored)
+	for key, row := range s.rows {
+		if revision, ok := superseded[row.ResponseID]; ok && row.Revision < revision {
+			delete(s.rows, key)
+		}
+	}
+
+	var inserted int64
+	for _, row := range rows {
+		key := row.EventID + "/" + row.QuestionID
+		if _, ok := s.rows[key]; ok {
+			continue
+		}
+		s.rows[key] = row
+		inserted++
+	}
+	return inserted, nil
+}
+
+func (s *memoryStore) PurgeFormResponses(_ context.Context, formID string, before time.Time, anonymize bool) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !anonymize {
+		for responseID, timing := range s.timings {
+			if timing.FormID == formID && timing.SubmittedAt.Before(before) {
+				delete(s.timings, responseID)
+			}
+		}
+	}
+	purged := make(map[string]bool)
+	for key, row := range s.rows {
+		if row.FormID != formID || !row.SubmittedAt.Before(before) {
+			continue
+		}
+		if !anonymize {
+			delete(s.rows, key)
+		} else if row.RespondentID != "" || !row.IsAnonymous {
+			row.RespondentID, row.IsAnonymous = "", true
+			s.rows[key] = row
+		} else {
+			continue
+		}
+		purged[row.ResponseID] = true
+	}
+	return int64(len(purged)), nil
+}
+
+func (s *memoryStore) DeleteResponses(_ context.Context, formID string, responseIDs []string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make(map[string]bool)
+	for _, id := range responseIDs {
+		ids[id] = true
+		if s.timings[id].FormID == formID {
+			delete(s.timings, id)
+		}
+	}
+	deleted := make(map[string]bool)
+	for key, row := range s.rows {
+		if row.FormID == formID && ids[row.ResponseID] {
+			delete(s.rows, key)
+			deleted[row.ResponseID] = true
+		}
+	}
+	return int64(len(deleted)), nil
+}
+
+func (s *memoryStore) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.rows)
+}
+
+func (s *memoryStore) responseRows(responseID string) []AnswerRow {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var rows []AnswerRow
+	for _, row := range s.rows {
+		if row.ResponseID == responseID {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// submissions builds the form.response.created events the response service
+// publishes for n submissions. Every third response skips a question.
+func submissions(n int) ([]*kafka.Message, int) {
+	messages := make([]*kafka.Message, 0, n)
+	expectedRows := 0
+	for i := 0; i < n; i++ {
+		answers := map[string]interface{}{
+			"q_name":   fmt.Sprintf("Respondent %d", i),
+			"q_rating": i%5 + 1,
+			"q_topics": []string{"pricing", "support"},
+		}
+		if i%3 != 0 {
+			answers["q_comment"] = "Looks good"
+		}
+		expectedRows += len(answers)
+
+		messages = append(messages, &kafka.Message{
+			ID:        fmt.Sprintf("response-created-r%d", i),
+			EventType: ResponseCreatedEventType,
+			Source:    "response-service",
+			Topic:     "app.form.response.created",
+			Data: map[string]interface{}{
+				"response_id":  fmt.Sprintf("r%d", i),
+				"form_id":      "form-1",
+				"form_version": 2,
+				"answers_hash": fmt.Sprintf("sha256:%064d", i),
+				"answers":      answers,
+				"respondent":   map[string]interface{}{"id": fmt.Sprintf("user-%d", i%40), "source": "web"},
+				"submitted_at": time.Date(2026, 5, 1, 12, 0, i%60, 0, time.UTC).Format(time.RFC3339),
+			},
+			Metadata: kafka.MessageMetadata{Timestamp: time.Now()},
+		})
+	}
+	return messages, expectedRows
+}
+
+// consumerRun simulates one life of the projection consumer over a partition
+// log: batches are handled from the committed offset and the offset is only
+// committed after a batch succeeds. With killAfter > 0 the consumer dies right
+// after handling that many batches, before committing the last one.
+func consumerRun(t *testing.T, store Store, log []*kafka.Message, committed *int, batchSize, killAfter int) *Metrics {
+	t.Helper()
+
+	metrics := NewMetrics(prometheus.NewRegistry())
+	projection := NewResponseProjection(config.ResponseProjectionConfig{
+		Topics:  []string{"app.form.response.created", "app.form.response.updated"},
+		GroupID: "test",
+	}, store, metrics, nil)
+
+	handled := 0
+	for *committed < len(log) {
+		end := *committed + batchSize
+		if end > len(log) {
+			end = len(log)
+		}
+
+		if err := projection.HandleBatch(context.Background(), log[*committed:end]); err != nil {
+			// The session ends and the batch is consumed again
+			continue
+		}
+		handled++
+		if handled == killAfter {
+			return metrics
+		}
+		*committed = end
+	}
+	return metrics
+}
+
+func runRestartScenario(t *testing.T, store *flakyStore, count func() int) {
+	log, expectedRows := submissions(500)
+
+	// The response service retried one publish, so the log holds a duplicate
+	log = append(log[:250], append([]*kafka.Message{log[249]}, log[250:]...)...)
+
+	committed := 0
+	first := consumerRun(t, store, log, &committed, 64, 3)
+	if committed != 128 {
+		t.Fatalf("expected the killed consumer to have committed 128 messages, got %d", committed)
+	}
+	if got := testutil.ToFloat64(first.Events.WithLabelValues(responseProjectionName, "projected")); got != 192 {
+		t.Errorf("expected 192 events projected before the kill, got %v", got)
+	}
+
+	// The restarted consumer reprocesses the uncommitted batch and survives
+	// a transient write failure
+	store.failNext = true
+	second := consumerRun(t, store, log, &committed, 64, 0)
+	if committed != len(log) {
+		t.Fatalf("expected the whole log to be committed, got %d of %d", committed, len(log))
+	}
+
+	if got := count(); got != expectedRows {
+		t.Errorf("expected exactly %d projected rows, got %d", expectedRows, got)
+	}
+	if got := testutil.ToFloat64(second.DuplicateRows.WithLabelValues(responseProjectionName)); got == 0 {
+		t.Error("expected the replayed batch and duplicate publish to be recorded as duplicates")
+	}
+	if got := testutil.ToFloat64(second.Events.WithLabelValues(responseProjectionName, "failed")); got == 0 {
+		t.Error("expected the failed write to be recorded")
+	}
+}
+
+func TestResponseProjectionSurvivesRestart(t *testing.T) {
+	memory := newMemoryStore()
+	runRestartScenario(t, &flakyStore{Store: memory}, memory.count)
+}
+
+// TestResponseProjectionSurvivesRestartPostgres runs the restart scenario
+// against a real database when EVENTBUS_TEST_DATABASE_URL is set
+func TestResponseProjectionSurvivesRestartPostgres(t *testing.T) {
+	dsn := os.Getenv("EVENTBUS_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("EVENTBUS_TEST_DATABASE_URL not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if _, err := db.ExecContext(ctx, "DROP TABLE IF EXISTS response_events"); err != nil {
+		t.Fatalf("failed to reset table: %v", err)
+	}
+	postgres := NewPostgresStore(db)
+	if err := postgres.EnsureSchema(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	runRestartScenario(t, &flakyStore{Store: postgres}, func() int {
+		var count int
+		if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM response_events").Scan(&count); err != nil {
+			t.Fatalf("failed to count rows: %v", err)
+		}
+		return count
+	})
+}
+
+// flakyStore fails the next write when asked to
+type flakyStore struct {
+	Store
+	failNext bool
+}
+
+func (s *flakyStore) InsertAnswerRows(ctx context.Context, rows []AnswerRow) (int64, error) {
+	if s.failNext {
+		s.failNext = false
+		return 0, errors.New("connection reset")
+	}
+	return s.Store.InsertAnswerRows(ctx, rows)
+}
+
+func TestHandleBatchSkipsUnprojectableEvents(t *testing.T) {
+	store := newMemoryStore()
+	metrics := NewMetrics(prometheus.NewRegistry())
+	projection := NewResponseProjection(config.ResponseProjectionConfig{Topics: []string{"t"}, GroupID: "g"}, store, metrics, nil)
+
+	valid, _ := submissions(1)
+	err := projection.HandleBatch(context.Background(), []*kafka.Message{
+		valid[0],
+		{ID: "e-2", EventType: "form.created", Data: map[string]interface{}{}},
+		{ID: "e-3", EventType: ResponseCreatedEventType, Data: map[string]interface{}{"form_id": "form-1"}},
+		{ID: "e-4", EventType: ResponseCreatedEventType, Data: []byte(`not json`)},
+	})
+	if err != nil {
+		t.Fatalf("batch failed: %v", err)
+	}
+
+	if got := store.count(); got != 3 {
+		t.Errorf("expected 3 rows from the valid event, got %d", got)
+	}
+	if got := testutil.ToFloat64(metrics.Events.WithLabelValues(responseProjectionName, "invalid")); got != 2 {
+		t.Errorf("expected 2 invalid events, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.Events.WithLabelValues(responseProjectionName, "skipped")); got != 1 {
+		t.Errorf("expected 1 skipped event, got %v", got)
+	}
+}
+
+func TestBuildInsertStatement(t *testing.T) {
+	rows := []AnswerRow{
+		{EventID: "e-1", QuestionID: "q1", Answer: json.RawMessage(`"a"`)},
+		{EventID: "e-1", QuestionID: "q2", Answer: json.RawMessage(`"b"`), RespondentID: "user-1", PresentedOrder: []string{"b", "a"}, IsTest: true, QuestionKey: "plan"},
+	}
+
+	query, args := buildInsertStatement(rows)
+	if len(args) != 2*len(answerRowColumns) {
+		t.Fatalf("expected %d args, got %d", 2*len(answerRowColumns), len(args))
+	}
+	want := "($15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28) ON CONFLICT (event_id, question_id) DO NOTHING"
+	if len(query) < len(want) || query[len(query)-len(want):] != want {
+		t.Errorf("unexpected statement: %s", query)
+	}
+	if args[8] != nil || args[22] != "user-1" {
+		t.Errorf("expected an empty respondent to be written as NULL, got %v and %v", args[8], args[22])
+	}
+	if args[11] != nil || args[25] != `["b","a"]` {
+		t.Errorf("expected the presented order as JSON, NULL when absent, got %v and %v", args[11], args[25])
+	}
+	if args[13] != nil || args[27] != "plan" {
+		t.Errorf("expected the question key, NULL when absent, got %v and %v", args[13], args[27])
+	}
+	if args[12] != false || args[26] != true {
+		t.Errorf("expected the test flag of each row, got %v and %v", args[12], args[26])
+	}
+}
+
+// edit builds the form.response.updated event of a revision of response r0
+func edit(revision int, comment string) *kafka.Message {
+	return &kafka.Message{
+		ID:        fmt.Sprintf("response-updated-r0-v%d", revision),
+		EventType: ResponseUpdatedEventType,
+		Source:    "response-service",
+		Topic:     "app.form.response.updated",
+		Data: map[string]interface{}{
+			"response_id":  "r0",
+			"form_id":      "form-1",
+			"form_version": 2,
+			"revision":     revision,
+			"answers_hash": fmt.Sprintf("sha256:%064d", revision),
+			"answers":      map[string]interface{}{"q_name": "Respondent 0", "q_comment": comment},
+			"submitted_at": time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC).Format(time.RFC3339),
+		},
+	}
+}
+
+func TestResponseUpdateReplacesEarlierRevision(t *testing.T) {
+	store := newMemoryStore()
+	projection := NewResponseProjection(config.ResponseProjectionConfig{Topics: []string{"t"}, GroupID: "g"}, store, NewMetrics(prometheus.NewRegistry()), nil)
+	ctx := context.Background()
+
+	created, _ := submissions(2)
+	if err := projection.HandleBatch(ctx, created); err != nil {
+		t.Fatal(err)
+	}
+	if err := projection.HandleBatch(ctx, []*kafka.Message{edit(2, "first edit")}); err != nil {
+		t.Fatal(err)
+	}
+	// A redelivered older revision must not bring back stale answers
+	if err := projection.HandleBatch(ctx, []*kafka.Message{edit(3, "second edit"), edit(2, "first edit"), created[0]}); err != nil {
+		t.Fatal(err)
+	}
+
+	rows := store.responseRows("r0")
+	if len(rows) != 2 {
+		t.Fatalf("expected the 2 answers of the latest revision, got %d rows", len(rows))
+	}
+	for _, row := range rows {
+		if row.Revision != 3 {
+			t.Errorf("expected revision 3, got %d for %s", row.Revision, row.QuestionID)
+		}
+		if row.QuestionID == "q_comment" && string(row.Answer) != `"second edit"` {
+			t.Errorf("expected the latest answer, got %s", row.Answer)
+		}
+	}
+	if got := len(store.responseRows("r1")); got != 4 {
+		t.Errorf("expected other responses to be untouched, got %d rows", got)
+	}
+}
+
+func purge(id, mode string, before time.Time) *kafka.Message {
+	return &kafka.Message{
+		ID:        id,
+		EventType: ResponsePurgedEventType,
+		Source:    "response-service",
+		Data: map[string]interface{}{
+			"form_id":       "form-1",
+			"mode":          mode,
+			"count":         30,
+			"purged_before": before.Format(time.RFC3339),
+		},
+	}
+}
+
+func TestResponsePurge(t *testing.T) {
+	store := newMemoryStore()
+	metrics := NewMetrics(prometheus.NewRegistry())
+	projection := NewResponseProjection(config.ResponseProjectionConfig{Topics: []string{"t"}, GroupID: "g"}, store, metrics, nil)
+	ctx := context.Background()
+
+	// Responses are submitted a second apart from 12:00:00
+	created, _ := submissions(60)
+	if err := projection.HandleBatch(ctx, created); err != nil {
+		t.Fatal(err)
+	}
+
+	// Purges follow the responses of their batch
+	anonymize := purge("purge-1", PurgeModeAnonymize, time.Date(2026, 5, 1, 12, 0, 30, 0, time.UTC))
+	more, _ := submissions(70)
+	if err := projection.HandleBatch(ctx, append(more[60:61], anonymize)); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"r0", "r29", "r60"} {
+		for _, row := range store.responseRows(id) {
+			if row.RespondentID != "" || !row.IsAnonymous {
+				t.Fatalf("%s: respondent %q, want it anonymized", id, row.RespondentID)
+			}
+		}
+	}
+	if rows := store.responseRows("r30"); len(rows) == 0 || rows[0].RespondentID == "" {
+		t.Errorf("r30 was submitted at the cutoff, want it untouched")
+	}
+
+	before := store.count()
+	if err := projection.HandleBatch(ctx, []*kafka.Message{
+		purge("purge-2", PurgeModeDelete, time.Date(2026, 5, 1, 12, 0, 10, 0, time.UTC)),
+		purge("purge-3", "shred", time.Now()),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(store.responseRows("r9")) + len(store.responseRows("r60")); got != 0 {
+		t.Errorf("%d rows of deleted responses left", got)
+	}
+	if deleted := before - store.count(); deleted == 0 || len(store.responseRows("r10")) == 0 {
+		t.Errorf("deleted %d rows, want the responses before 12:00:10 only", deleted)
+	}
+	if got := testutil.ToFloat64(metrics.Events.WithLabelValues(responseProjectionName, "invalid")); got != 1 {
+		t.Errorf("expected the purge of an unknown mode to be invalid, got %v invalid events", got)
+	}
+}
+
+func deletion(id string, responseIDs ...string) *kafka.Message {
+	return &kafka.Message{
+		ID:        id,
+		EventType: ResponsesDeletedEventType,
+		Source:    "form-service",
+		Data: map[string]interface{}{
+			"form_id":      "form-1",
+			"deletion_id":  "deletion-1",
+			"response_ids": responseIDs,
+		},
+	}
+}
+
+func TestResponseDeletion(t *testing.T) {
+	store := newMemoryStore()
+	metrics := NewMetrics(prometheus.NewRegistry())
+	projection := NewResponseProjection(config.ResponseProjectionConfig{Topics: []string{"t"}, GroupID: "g"}, store, metrics, nil)
+	ctx := context.Background()
+
+	created, _ := submissions(10)
+	if err := projection.HandleBatch(ctx, created); err != nil {
+		t.Fatal(err)
+	}
+	before := store.count()
+	want := len(store.responseRows("r1")) + len(store.responseRows("r2")) + len(store.responseRows("r5"))
+
+	// A batch redelivered deletes nothing more
+	batch := []*kafka.Message{deletion("deletion-1-batch-1", "r1", "r2"), deletion("deletion-1-batch-2", "r5")}
+	for i := 0; i < 2; i++ {
+		if err := projection.HandleBatch(ctx, batch); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, id := range []string{"r1", "r2", "r5"} {
+		if rows := store.responseRows(id); len(rows) != 0 {
+			t.Errorf("%s: %d rows left, want it deleted", id, len(rows))
+		}
+	}
+	if deleted := before - store.count(); deleted != want {
+		t.Errorf("deleted %d rows, want the rows of the 3 responses only", deleted)
+	}
+
+	if err := projection.HandleBatch(ctx, []*kafka.Message{deletion("deletion-2")}); err != nil {
+		t.Fatal(err)
+	}
+	if got := testutil.ToFloat64(metrics.Events.WithLabelValues(responseProjectionName, "invalid")); got != 1 {
+		t.Errorf("expected a deletion without responses to be invalid, got %v invalid events", got)
+	}
+}
+
+func TestResponseEventPresentedOrder(t *testing.T) {
+	event, err := ParseResponseEvent(&kafka.Message{
+		ID: "response-created-r1",
+		Data: map[string]interface{}{
+			"response_id":     "r1",
+			"form_id":         "form-1",
+			"answers_hash":    "sha256:1",
+			"answers":         map[string]interface{}{"q_plan": "pro", "q_name": "Ada"},
+			"presented_order": map[string]interface{}{"q_plan": []string{"team", "pro", "free", "other"}},
+			"question_keys":   map[string]interface{}{"q_plan": "plan"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ParseResponseEvent: %v", err)
+	}
+
+	rows := event.Rows("response-created-r1")
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(rows))
+	}
+	// Rows are ordered by question ID: q_name, then q_plan
+	if rows[0].PresentedOrder != nil {
+		t.Errorf("expected no presented order for q_name, got %v", rows[0].PresentedOrder)
+	}
+	if got := rows[1].PresentedOrder; len(got) != 4 || got[0] != "team" || got[3] != "other" {
+		t.Errorf("expected the presented order of q_plan, got %v", got)
+	}
+	if rows[0].QuestionKey != "" || rows[1].QuestionKey != "plan" {
+		t.Errorf("expected the key of q_plan alone, got %q and %q", rows[0].QuestionKey, rows[1].QuestionKey)
+	}
+	// The answer stays keyed by option, whatever position it was shown at
+	if string(rows[1].Answer) != `"pro"` {
+		t.Errorf("expected the option key as answer, got %s", rows[1].Answer)
+	}
+}
+
+func TestResponseEventTest(t *testing.T) {
+	event, err := ParseResponseEvent(&kafka.Message{
+		ID: "response-created-r1",
+		Data: map[string]interface{}{
+			"response_id":  "r1",
+			"form_id":      "form-1",
+			"answers_hash": "sha256:1",
+			"answers":      map[string]interface{}{"q_plan": "pro", "q_name": "Ada"},
+			"is_test":      true,
+		},
+	})
+	if err != nil {
+		t.Fatalf("ParseResponseEvent: %v", err)
+	}
+	for _, row := range event.Rows("response-created-r1") {
+		if !row.IsTest {
+			t.Errorf("expected the row of %s flagged as test", row.QuestionID)
+		}
+	}
+}
+
+func TestParseTimings(t *testing.T) {
+	timings, err := ParseTimings(json.RawMessage(`{
+		"q_name": {"focus_ms": 8400.6, "revisits": 1},
+		"q_plan": {"focus_ms": 0},
+		"q_comment": {"focus_ms": 86400000, "revisits": 5000}
+	}`))
+	if err != nil {
+		t.Fatalf("ParseTimings: %v", err)
+	}
+	want := map[string]QuestionTiming{
+		"q_name":    {FocusMs: 8401, Revisits: 1},
+		"q_plan":    {FocusMs: 0, Revisits: 0},
+		"q_comment": {FocusMs: MaxFocusMs, Revisits: MaxRevisits},
+	}
+	for questionID, timing := range want {
+		if timings[questionID] != timing {
+			t.Errorf("%s: got %+v, want %+v", questionID, timings[questionID], timing)
+		}
+	}
+
+	tooMany := make(map[string]QuestionTiming, MaxTimedQuestions+1)
+	for i := 0; i <= MaxTimedQuestions; i++ {
+		tooMany[fmt.Sprintf("q%d", i)] = QuestionTiming{FocusMs: 1000}
+	}
+	encoded, _ := json.Marshal(tooMany)
+
+	for name, payload := range map[string]string{
+		"not an object":      `[{"focus_ms": 1000}]`,
+		"a number":           `1200`,
+		"null":               `null`,
+		"timing not object":  `{"q_name": 1200}`,
+		"null timing":        `{"q_name": null}`,
+		"missing focus":      `{"q_name": {"revisits": 2}}`,
+		"negative focus":     `{"q_name": {"focus_ms": -5}}`,
+		"focus as string":    `{"q_name": {"focus_ms": "1200"}}`,
+		"negative revisits":  `{"q_name": {"focus_ms": 1200, "revisits": -1}}`,
+		"fractional revisit": `{"q_name": {"focus_ms": 1200, "revisits": 1.5}}`,
+		"unknown field":      `{"q_name": {"focus_ms": 1200, "answer": "Ada"}}`,
+		"empty question ID":  `{"": {"focus_ms": 1200}}`,
+		"too many questions": string(encoded),
+	} {
+		if _, err := ParseTimings(json.RawMessage(payload)); !errors.Is(err, ErrInvalidTimings) {
+			t.Errorf("%s: err = %v, want ErrInvalidTimings", name, err)
+		}
+	}
+}
+
+func TestResponseProjectionTimings(t *testing.T) {
+	store := newMemoryStore()
+	projection := NewResponseProjection(config.ResponseProjectionConfig{Topics: []string{"t"}, GroupID: "g"}, store, NewMetrics(prometheus.NewRegistry()), nil)
+	ctx := context.Background()
+
+	created, _ := submissions(3)
+	created[0].Data.(map[string]interface{})["timings"] = map[string]interface{}{
+		"q_name":   map[string]interface{}{"focus_ms": 4200, "revisits": 1},
+		"q_rating": map[string]interface{}{"focus_ms": 1800},
+	}
+	created[1].Data.(map[string]interface{})["timings"] = map[string]interface{}{
+		"q_name": map[string]interface{}{"focus_ms": -1},
+	}
+	updated := edit(2, "edited")
+	updated.Data.(map[string]interface{})["timings"] = map[string]interface{}{
+		"q_name": map[string]interface{}{"focus_ms": 99},
+	}
+	if err := projection.HandleBatch(ctx, append(created, updated)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Malformed timings are dropped, the answers of their response kept
+	if len(store.timings) != 1 {
+		t.Fatalf("expected the timings of r0 only, got %v", store.timings)
+	}
+	if got := store.timings["r0"].Questions; got["q_name"] != (QuestionTiming{FocusMs: 4200, Revisits: 1}) || got["q_rating"].FocusMs != 1800 {
+		t.Errorf("timings of r0 = %+v, want those of the submission", got)
+	}
+	if len(store.responseRows("r1")) == 0 {
+		t.Error("the answers of the response with malformed timings were dropped")
+	}
+
+	if err := projection.HandleBatch(ctx, []*kafka.Message{deletion("deletion-1-batch-1", "r0")}); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.timings) != 0 {
+		t.Errorf("timings of deleted responses left: %v", store.timings)
+	}
+}
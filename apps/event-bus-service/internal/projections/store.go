@@ -0,0 +1,518 @@
+package projections
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Schema creates the response_events, response_timings and funnel
+// projection tables. It is kept in line with scripts/init-db.sql so a fresh database can
+// be projected into without running the init script first.
+const Schema = `
+CREATE TABLE IF NOT EXISTS response_events (
+    event_id VARCHAR(255) NOT NULL,
+    question_id VARCHAR(255) NOT NULL,
+    response_id VARCHAR(255) NOT NULL,
+    form_id VARCHAR(255) NOT NULL,
+    form_version INTEGER NOT NULL DEFAULT 1,
+    revision INTEGER NOT NULL DEFAULT 1,
+    answer JSONB,
+    answers_hash VARCHAR(128) NOT NULL,
+    respondent_id VARCHAR(255),
+    is_anonymous BOOLEAN NOT NULL DEFAULT FALSE,
+    submitted_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    projected_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (event_id, question_id)
+);
+ALTER TABLE response_events ADD COLUMN IF NOT EXISTS revision INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE response_events ADD COLUMN IF NOT EXISTS presented_order JSONB;
+ALTER TABLE response_events ADD COLUMN IF NOT EXISTS is_test BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE response_events ADD COLUMN IF NOT EXISTS question_key VARCHAR(64);
+CREATE INDEX IF NOT EXISTS idx_response_events_form_question ON response_events(form_id, question_id);
+CREATE INDEX IF NOT EXISTS idx_response_events_form_question_key ON response_events(form_id, question_key);
+CREATE INDEX IF NOT EXISTS idx_response_events_response_id ON response_events(response_id);
+CREATE INDEX IF NOT EXISTS idx_response_events_submitted_at ON response_events(submitted_at);
+CREATE INDEX IF NOT EXISTS idx_response_events_respondent_id ON response_events(respondent_id);
+CREATE TABLE IF NOT EXISTS response_timings (
+    response_id VARCHAR(255) PRIMARY KEY,
+    form_id VARCHAR(255) NOT NULL,
+    timings JSONB NOT NULL,
+    submitted_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    is_test BOOLEAN NOT NULL DEFAULT FALSE,
+    projected_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_response_timings_form_id ON response_timings(form_id, submitted_at);
+CREATE TABLE IF NOT EXISTS form_funnel_sessions (
+    form_id VARCHAR(255) NOT NULL,
+    session_id VARCHAR(255) NOT NULL,
+    stage VARCHAR(16) NOT NULL,
+    day DATE NOT NULL,
+    PRIMARY KEY (form_id, session_id, stage)
+);
+CREATE INDEX IF NOT EXISTS idx_form_funnel_sessions_day ON form_funnel_sessions(day);
+CREATE TABLE IF NOT EXISTS form_funnel_daily (
+    form_id VARCHAR(255) NOT NULL,
+    day DATE NOT NULL,
+    views BIGINT NOT NULL DEFAULT 0,
+    starts BIGINT NOT NULL DEFAULT 0,
+    submissions BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (form_id, day)
+);
+`
+
+// answerRowColumns are the columns written for each answer row, in the order
+// of the statement placeholders
+var answerRowColumns = []string{
+	"event_id", "question_id", "response_id", "form_id", "form_version", "revision",
+	"answer", "answers_hash", "respondent_id", "is_anonymous", "submitted_at",
+	"presented_order", "is_test", "question_key",
+}
+
+// maxRowsPerStatement keeps a single INSERT well below PostgreSQL's limit of
+// 65535 bind parameters
+const maxRowsPerStatement = 1000
+
+// Store persists projected answer rows
+type Store interface {
+	// InsertAnswerRows writes rows, skipping any whose (event_id, question_id)
+	// already exists, and returns the number of rows actually inserted. Only
+	// the latest revision of a response is kept: rows of a newer revision
+	// replace the stored rows of that response, and rows of a revision older
+	// than the stored one are skipped.
+	InsertAnswerRows(ctx context.Context, rows []AnswerRow) (int64, error)
+	// InsertTimings writes the timings of submissions, skipping responses
+	// whose timings are stored already
+	InsertTimings(ctx context.Context, timings []ResponseTimings) error
+	// PurgeFormResponses deletes the rows and timings of the responses of a
+	// form submitted before a time, or with anonymize strips their
+	// respondent, and returns the number of responses purged
+	PurgeFormResponses(ctx context.Context, formID string, before time.Time, anonymize bool) (int64, error)
+	// DeleteResponses deletes the rows and timings of responses of a form by
+	// ID and returns the number of responses deleted
+	DeleteResponses(ctx context.Context, formID string, responseIDs []string) (int64, error)
+}
+
+// PostgresStore writes answer rows into the response_events table
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore creates a store writing through db
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// EnsureSchema creates the projection tables and their indexes if missing
+func (s *PostgresStore) EnsureSchema(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, Schema); err != nil {
+		return fmt.Errorf("failed to create projection tables: %w", err)
+	}
+	return nil
+}
+
+// InsertAnswerRows writes rows with multi-row inserts in one transaction, so a
+// batch is either fully projected or not at all. Rows already projected by an
+// earlier delivery of the same event are skipped by the primary key.
+//
+// Revisions are compared per response. All events of a response share the
+// form ID as partition key, so they are projected by a single consumer.
+func (s *PostgresStore) InsertAnswerRows(ctx context.Context, rows []AnswerRow) (int64, error) {
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stored, err := storedRevisions(ctx, tx, rows)
+	if err != nil {
+		return 0, err
+	}
+	rows, superseded := LatestRevisionRows(rows, stored)
+	for responseID, revision := range superseded {
+		if _, err := tx.ExecContext(ctx,
+			"DELETE FROM response_events WHERE response_id = $1 AND revision < $2",
+			responseID, revision); err != nil {
+			return 0, fmt.Errorf("failed to replace earlier revision of response %s: %w", responseID, err)
+		}
+	}
+
+	var inserted int64
+	for start := 0; start < len(rows); start += maxRowsPerStatement {
+		end := start + maxRowsPerStatement
+		if end > len(rows) {
+			end = len(rows)
+		}
+
+		query, args := buildInsertStatement(rows[start:end])
+		result, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return 0, fmt.Errorf("failed to insert answer rows: %w", err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("failed to count inserted rows: %w", err)
+		}
+		inserted += affected
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit answer rows: %w", err)
+	}
+	return inserted, nil
+}
+
+// InsertTimings writes timings with a single insert. Timings carry no
+// respondent, so they are kept as submitted when the response is edited or
+// anonymized.
+func (s *PostgresStore) InsertTimings(ctx context.Context, timings []ResponseTimings) error {
+	if len(timings) == 0 {
+		return nil
+	}
+
+	var b strings.Builder
+	args := make([]interface{}, 0, len(timings)*5)
+	b.WriteString("INSERT INTO response_timings (response_id, form_id, timings, submitted_at, is_test) VALUES ")
+	for i, timing := range timings {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "($%d, $%d, $%d, $%d, $%d)", i*5+1, i*5+2, i*5+3, i*5+4, i*5+5)
+		encoded, err := json.Marshal(timing.Questions)
+		if err != nil {
+			return fmt.Errorf("failed to encode timings of response %s: %w", timing.ResponseID, err)
+		}
+		args = append(args, timing.ResponseID, timing.FormID, string(encoded), timing.SubmittedAt, timing.IsTest)
+	}
+	b.WriteString(" ON CONFLICT (response_id) DO NOTHING")
+
+	if _, err := s.db.ExecContext(ctx, b.String(), args...); err != nil {
+		return fmt.Errorf("failed to insert response timings: %w", err)
+	}
+	return nil
+}
+
+// AnonymizeRespondent strips the respondent ID from the answer rows of a
+// respondent and marks them anonymous, keeping the answers for the form
+// owners. It returns the number of responses anonymized, none when called
+// again.
+func (s *PostgresStore) AnonymizeRespondent(ctx context.Context, respondentID string) (int64, error) {
+	var anonymized int64
+	err := s.db.QueryRowContext(ctx, `
+		WITH anonymized AS (
+			UPDATE response_events SET respondent_id = NULL, is_anonymous = TRUE
+			WHERE respondent_id = $1
+			RETURNING response_id
+		)
+		SELECT COUNT(DISTINCT response_id) FROM anonymized`, respondentID).Scan(&anonymized)
+	if err != nil {
+		return 0, fmt.Errorf("failed to anonymize responses: %w", err)
+	}
+	return anonymized, nil
+}
+
+// PurgeFormResponses purges the rows of the responses of a form submitted
+// before a time. Rows already anonymized are not counted again.
+func (s *PostgresStore) PurgeFormResponses(ctx context.Context, formID string, before time.Time, anonymize bool) (int64, error) {
+	if !anonymize {
+		if _, err := s.db.ExecContext(ctx,
+			"DELETE FROM response_timings WHERE form_id = $1 AND submitted_at < $2", formID, before); err != nil {
+			return 0, fmt.Errorf("failed to purge response timings: %w", err)
+		}
+	}
+	query := `
+		WITH purged AS (
+			DELETE FROM response_events WHERE form_id = $1 AND submitted_at < $2
+			RETURNING response_id
+		)
+		SELECT COUNT(DISTINCT response_id) FROM purged`
+	if anonymize {
+		query = `
+		WITH purged AS (
+			UPDATE response_events SET respondent_id = NULL, is_anonymous = TRUE
+			WHERE form_id = $1 AND submitted_at < $2 AND (respondent_id IS NOT NULL OR NOT is_anonymous)
+			RETURNING response_id
+		)
+		SELECT COUNT(DISTINCT response_id) FROM purged`
+	}
+	var purged int64
+	if err := s.db.QueryRowContext(ctx, query, formID, before).Scan(&purged); err != nil {
+		return 0, fmt.Errorf("failed to purge responses: %w", err)
+	}
+	return purged, nil
+}
+
+// DeleteResponses deletes the rows of responses of a form by ID. Responses
+// deleted already are not counted.
+func (s *PostgresStore) DeleteResponses(ctx context.Context, formID string, responseIDs []string) (int64, error) {
+	if _, err := s.db.ExecContext(ctx,
+		"DELETE FROM response_timings WHERE form_id = $1 AND response_id = ANY($2)", formID, pq.Array(responseIDs)); err != nil {
+		return 0, fmt.Errorf("failed to delete response timings: %w", err)
+	}
+
+	var deleted int64
+	err := s.db.QueryRowContext(ctx, `
+		WITH deleted AS (
+			DELETE FROM response_events WHERE form_id = $1 AND response_id = ANY($2)
+			RETURNING response_id
+		)
+		SELECT COUNT(DISTINCT response_id) FROM deleted`, formID, pq.Array(responseIDs)).Scan(&deleted)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete responses: %w", err)
+	}
+	return deleted, nil
+}
+
+// CountResponses returns the number of responses to a form submitted since
+// a time, as projected so far, test submissions aside
+func (s *PostgresStore) CountResponses(ctx context.Context, formID string, since time.Time) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(DISTINCT response_id) FROM response_events
+		WHERE form_id = $1 AND submitted_at >= $2 AND NOT is_test`, formID, since).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count responses: %w", err)
+	}
+	return count, nil
+}
+
+// Submission is a response of a respondent, as archived by data exports
+type Submission struct {
+	ResponseID  string                     `json:"response_id"`
+	FormID      string                     `json:"form_id"`
+	Revision    int                        `json:"revision"`
+	SubmittedAt time.Time                  `json:"submitted_at"`
+	Answers     map[string]json.RawMessage `json:"answers"`
+}
+
+// RespondentSubmissions returns the responses of a respondent, oldest first
+func (s *PostgresStore) RespondentSubmissions(ctx context.Context, respondentID string) ([]*Submission, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT response_id, form_id, revision, submitted_at, question_id, answer
+		FROM response_events WHERE respondent_id = $1
+		ORDER BY submitted_at, response_id, question_id`, respondentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read responses: %w", err)
+	}
+	defer rows.Close()
+
+	var submissions []*Submission
+	for rows.Next() {
+		var (
+			row        Submission
+			questionID string
+			answer     []byte
+		)
+		if err := rows.Scan(&row.ResponseID, &row.FormID, &row.Revision, &row.SubmittedAt, &questionID, &answer); err != nil {
+			return nil, fmt.Errorf("failed to read responses: %w", err)
+		}
+		last := len(submissions) - 1
+		if last < 0 || submissions[last].ResponseID != row.ResponseID {
+			row.Answers = make(map[string]json.RawMessage)
+			submissions = append(submissions, &row)
+			last++
+		}
+		if answer != nil {
+			submissions[last].Answers[questionID] = answer
+		}
+	}
+	return submissions, rows.Err()
+}
+
+// storedRevisions returns the revision stored for each response of rows
+func storedRevisions(ctx context.Context, tx *sql.Tx, rows []AnswerRow) (map[string]int, error) {
+	seen := make(map[string]bool)
+	responseIDs := make([]string, 0)
+	for _, row := range rows {
+		if !seen[row.ResponseID] {
+			seen[row.ResponseID] = true
+			responseIDs = append(responseIDs, row.ResponseID)
+		}
+	}
+
+	result, err := tx.QueryContext(ctx,
+		"SELECT response_id, MAX(revision) FROM response_events WHERE response_id = ANY($1) GROUP BY response_id",
+		pq.Array(responseIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stored revisions: %w", err)
+	}
+	defer result.Close()
+
+	revisions := make(map[string]int)
+	for result.Next() {
+		var (
+			responseID string
+			revision   int
+		)
+		if err := result.Scan(&responseID, &revision); err != nil {
+			return nil, fmt.Errorf("failed to read stored revisions: %w", err)
+		}
+		revisions[responseID] = revision
+	}
+	return revisions, result.Err()
+}
+
+// LatestRevisionRows keeps the rows of the latest revision of each response,
+// given the revisions already stored. It also returns the responses whose
+// stored rows are superseded, with the revision replacing them.
+func LatestRevisionRows(rows []AnswerRow, stored map[string]int) ([]AnswerRow, map[string]int) {
+	latest := make(map[string]int, len(stored))
+	for responseID, revision := range stored {
+		latest[responseID] = revision
+	}
+	for _, row := range rows {
+		if row.Revision > latest[row.ResponseID] {
+			latest[row.ResponseID] = row.Revision
+		}
+	}
+
+	kept := rows[:0:0]
+	superseded := make(map[string]int)
+	for _, row := range rows {
+		if row.Revision < latest[row.ResponseID] {
+			continue
+		}
+		kept = append(kept, row)
+		if revision, ok := stored[row.ResponseID]; ok && revision < row.Revision {
+			superseded[row.ResponseID] = row.Revision
+		}
+	}
+	return kept, superseded
+}
+
+// buildInsertStatement builds one INSERT ... ON CONFLICT DO NOTHING for rows
+func buildInsertStatement(rows []AnswerRow) (string, []interface{}) {
+	var b strings.Builder
+	args := make([]interface{}, 0, len(rows)*len(answerRowColumns))
+
+	b.WriteString("INSERT INTO response_events (")
+	b.WriteString(strings.Join(answerRowColumns, ", "))
+	b.WriteString(") VALUES ")
+
+	for i, row := range rows {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString("(")
+		for j := range answerRowColumns {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, "$%d", i*len(answerRowColumns)+j+1)
+		}
+		b.WriteString(")")
+
+		var respondentID interface{}
+		if row.RespondentID != "" {
+			respondentID = row.RespondentID
+		}
+		var presentedOrder interface{}
+		if len(row.PresentedOrder) > 0 {
+			encoded, _ := json.Marshal(row.PresentedOrder)
+			presentedOrder = string(encoded)
+		}
+		var questionKey interface{}
+		if row.QuestionKey != "" {
+			questionKey = row.QuestionKey
+		}
+		args = append(args,
+			row.EventID, row.QuestionID, row.ResponseID, row.FormID, row.FormVersion, row.Revision,
+			string(row.Answer), row.AnswersHash, respondentID, row.IsAnonymous, row.SubmittedAt,
+			presentedOrder, row.IsTest, questionKey,
+		)
+	}
+
+	b.WriteString(" ON CONFLICT (event_id, question_id) DO NOTHING")
+	return b.String(), args
+}
+
+// InsertFunnelStages writes stages with multi-row inserts in one
+// transaction. Each statement inserts the stages not stored yet and adds
+// those inserted to the daily counts, so a stage delivered again is neither
+// stored nor counted twice. Stages must be unique in the batch.
+func (s *PostgresStore) InsertFunnelStages(ctx context.Context, stages []FunnelStage) (int64, error) {
+	if len(stages) == 0 {
+		return 0, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var counted int64
+	for start := 0; start < len(stages); start += maxRowsPerStatement {
+		end := start + maxRowsPerStatement
+		if end > len(stages) {
+			end = len(stages)
+		}
+
+		query, args := buildFunnelStatement(stages[start:end])
+		var inserted int64
+		if err := tx.QueryRowContext(ctx, query, args...).Scan(&inserted); err != nil {
+			return 0, fmt.Errorf("failed to insert funnel stages: %w", err)
+		}
+		counted += inserted
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit funnel stages: %w", err)
+	}
+	return counted, nil
+}
+
+// PruneFunnelSessions deletes the stages of sessions reached before a day.
+// The daily counts are kept; a stage delivered again after its session was
+// pruned is counted again.
+func (s *PostgresStore) PruneFunnelSessions(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM form_funnel_sessions WHERE day < $1", before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune funnel sessions: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// buildFunnelStatement builds the statement inserting stages into
+// form_funnel_sessions and adding those inserted to form_funnel_daily,
+// returning how many were inserted
+func buildFunnelStatement(stages []FunnelStage) (string, []interface{}) {
+	var b strings.Builder
+	args := make([]interface{}, 0, len(stages)*4)
+
+	b.WriteString("WITH counted AS (INSERT INTO form_funnel_sessions (form_id, session_id, stage, day) VALUES ")
+	for i, stage := range stages {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "($%d, $%d, $%d, $%d)", i*4+1, i*4+2, i*4+3, i*4+4)
+		args = append(args, stage.FormID, stage.SessionID, stage.Stage, stage.Day)
+	}
+	b.WriteString(` ON CONFLICT (form_id, session_id, stage) DO NOTHING
+		RETURNING form_id, stage, day
+	), daily AS (
+		INSERT INTO form_funnel_daily (form_id, day, views, starts, submissions)
+		SELECT form_id, day,
+			COUNT(*) FILTER (WHERE stage = 'view'),
+			COUNT(*) FILTER (WHERE stage = 'start'),
+			COUNT(*) FILTER (WHERE stage = 'submit')
+		FROM counted GROUP BY form_id, day
+		ON CONFLICT (form_id, day) DO UPDATE SET
+			views = form_funnel_daily.views + EXCLUDED.views,
+			starts = form_funnel_daily.starts + EXCLUDED.starts,
+			submissions = form_funnel_daily.submissions + EXCLUDED.submissions,
+			updated_at = NOW()
+	)
+	SELECT COUNT(*) FROM counted`)
+	return b.String(), args
+}
@@ -0,0 +1,93 @@
+package projections
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Limits of the timing beacons of a submission. Focus times and revisits
+// above their cap are clamped, as a tab left open on a question says nothing
+// about the question; payloads timing more questions are dropped.
+const (
+	MaxTimedQuestions  = 500
+	MaxQuestionIDBytes = 255
+	MaxFocusMs         = int64(time.Hour / time.Millisecond)
+	MaxRevisits        = 100
+)
+
+// ErrInvalidTimings is returned for timing payloads that can't be projected.
+// The answers of their response are projected without them.
+var ErrInvalidTimings = errors.New("invalid response timings")
+
+// QuestionTiming is how long a question held focus while the respondent
+// filled the form, and how often they returned to it after leaving it
+type QuestionTiming struct {
+	FocusMs  int64 `json:"focus_ms"`
+	Revisits int   `json:"revisits"`
+}
+
+// ResponseTimings is one row of the response_timings projection: the timings
+// of the questions of one submission
+type ResponseTimings struct {
+	ResponseID  string
+	FormID      string
+	SubmittedAt time.Time
+	IsTest      bool
+	Questions   map[string]QuestionTiming
+}
+
+// beacon is a question timing as sent by clients, whose timers may report
+// fractions of milliseconds
+type beacon struct {
+	FocusMs  *float64 `json:"focus_ms"`
+	Revisits *float64 `json:"revisits"`
+}
+
+// ParseTimings validates the timing payload of a submission, an object of
+// { focus_ms, revisits } by question ID, and caps its values. Focus times
+// are rounded to the millisecond; revisits default to 0.
+func ParseTimings(payload json.RawMessage) (map[string]QuestionTiming, error) {
+	var beacons map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &beacons); err != nil || beacons == nil {
+		return nil, fmt.Errorf("%w: want an object of timings by question ID", ErrInvalidTimings)
+	}
+	if len(beacons) > MaxTimedQuestions {
+		return nil, fmt.Errorf("%w: %d questions timed, at most %d", ErrInvalidTimings, len(beacons), MaxTimedQuestions)
+	}
+
+	timings := make(map[string]QuestionTiming, len(beacons))
+	for questionID, raw := range beacons {
+		if questionID == "" || len(questionID) > MaxQuestionIDBytes {
+			return nil, fmt.Errorf("%w: invalid question ID %q", ErrInvalidTimings, questionID)
+		}
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.DisallowUnknownFields()
+		var b beacon
+		if err := decoder.Decode(&b); err != nil {
+			return nil, fmt.Errorf("%w: question %s: want { focus_ms, revisits }", ErrInvalidTimings, questionID)
+		}
+
+		switch {
+		case b.FocusMs == nil:
+			return nil, fmt.Errorf("%w: question %s: focus_ms is required", ErrInvalidTimings, questionID)
+		case *b.FocusMs < 0:
+			return nil, fmt.Errorf("%w: question %s: negative focus_ms", ErrInvalidTimings, questionID)
+		case b.Revisits != nil && (*b.Revisits < 0 || *b.Revisits != math.Trunc(*b.Revisits)):
+			return nil, fmt.Errorf("%w: question %s: revisits must be a count", ErrInvalidTimings, questionID)
+		}
+
+		timing := QuestionTiming{FocusMs: MaxFocusMs}
+		if *b.FocusMs < float64(MaxFocusMs) {
+			timing.FocusMs = int64(math.Round(*b.FocusMs))
+		}
+		if b.Revisits != nil {
+			timing.Revisits = int(math.Min(*b.Revisits, MaxRevisits))
+		}
+		timings[questionID] = timing
+	}
+	return timings, nil
+}
@@ -0,0 +1,404 @@
+// Package publishing implements the event publishing path shared by the HTTP
+// and gRPC APIs of the Event Bus Service: request validation, conversion to
+// Kafka messages, publishing and the related metrics.
+package publishing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+)
+
+// Protocols the publishing metrics are labeled with
+const (
+	ProtocolHTTP = "http"
+	ProtocolGRPC = "grpc"
+)
+
+// MaxBatchSize is the largest number of events accepted in one batch
+const MaxBatchSize = 1000
+
+var (
+	// ErrInvalidEvent is returned when an event request fails validation
+	ErrInvalidEvent = errors.New("invalid event")
+	// ErrInvalidBatch is returned when a batch is empty or too large
+	ErrInvalidBatch = errors.New("invalid batch")
+)
+
+// EventRequest represents an event publishing request
+type EventRequest struct {
+	// ID is generated when empty
+	ID        string                 `json:"id,omitempty"`
+	EventType string                 `json:"event_type"`
+	Source    string                 `json:"source"`
+	Subject   string                 `json:"subject"`
+	Data      map[string]interface{} `json:"data"`
+	// RawData is the data as received, which DecodeEventRequest sets in place
+	// of Data so the payload is published without being decoded into maps
+	// and encoded again
+	RawData json.RawMessage   `json:"-"`
+	Headers map[string]string `json:"headers"`
+	Topic   string            `json:"topic"`
+	Key     string            `json:"key"`
+	// DeliverAt schedules the event for delivery at a later time. Only POST
+	// /events accepts it.
+	DeliverAt *time.Time `json:"deliver_at,omitempty"`
+}
+
+// Validate checks that the request carries everything needed to publish it
+func (r *EventRequest) Validate() error {
+	if r.EventType == "" {
+		return fmt.Errorf("%w: event_type is required", ErrInvalidEvent)
+	}
+	if r.Source == "" {
+		return fmt.Errorf("%w: source is required", ErrInvalidEvent)
+	}
+	if r.RawData != nil {
+		switch {
+		case bytes.Equal(r.RawData, null):
+			return fmt.Errorf("%w: data is required", ErrInvalidEvent)
+		case r.RawData[0] != '{':
+			return fmt.Errorf("%w: data must be a JSON object", ErrInvalidEvent)
+		}
+		return nil
+	}
+	if r.Data == nil {
+		return fmt.Errorf("%w: data is required", ErrInvalidEvent)
+	}
+	return nil
+}
+
+// DataMap returns the data of the request, decoding RawData when it is set
+func (r *EventRequest) DataMap() (map[string]interface{}, error) {
+	if r.RawData == nil {
+		return r.Data, nil
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(r.RawData, &data); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+	return data, nil
+}
+
+// payload is the data published for the request
+func (r *EventRequest) payload() interface{} {
+	if r.RawData != nil {
+		return r.RawData
+	}
+	return r.Data
+}
+
+var null = []byte("null")
+
+// envelope holds the attributes of both an EventRequest and a structured-mode
+// CloudEvent, so a body is decoded in one pass whichever it is. The data is
+// kept raw.
+type envelope struct {
+	SpecVersion *string           `json:"specversion"`
+	ID          string            `json:"id"`
+	EventType   string            `json:"event_type"`
+	Type        string            `json:"type"`
+	Source      string            `json:"source"`
+	Subject     string            `json:"subject"`
+	Data        json.RawMessage   `json:"data"`
+	Headers     map[string]string `json:"headers"`
+	Topic       string            `json:"topic"`
+	Key         string            `json:"key"`
+	DeliverAt   *time.Time        `json:"deliver_at"`
+}
+
+// DecodeEventRequest parses a request body holding either an EventRequest or
+// a structured-mode CloudEvent, told apart by the CloudEvent specversion
+// attribute. Decoding failures wrap ErrInvalidEvent.
+func DecodeEventRequest(body []byte) (*EventRequest, error) {
+	var env envelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+	if env.SpecVersion == nil {
+		return &EventRequest{
+			ID:        env.ID,
+			EventType: env.EventType,
+			Source:    env.Source,
+			Subject:   env.Subject,
+			RawData:   env.Data,
+			Headers:   env.Headers,
+			Topic:     env.Topic,
+			Key:       env.Key,
+			DeliverAt: env.DeliverAt,
+		}, nil
+	}
+
+	event := kafka.CloudEvent{
+		SpecVersion: *env.SpecVersion,
+		ID:          env.ID,
+		Source:      env.Source,
+		Type:        env.Type,
+		Subject:     env.Subject,
+	}
+	if err := event.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: invalid CloudEvent: %v", ErrInvalidEvent, err)
+	}
+	if len(env.Data) > 0 && !bytes.Equal(env.Data, null) && env.Data[0] != '{' {
+		return nil, fmt.Errorf("%w: CloudEvent data must be a JSON object", ErrInvalidEvent)
+	}
+	return &EventRequest{
+		ID:        event.ID,
+		EventType: event.Type,
+		Source:    event.Source,
+		Subject:   event.Subject,
+		RawData:   env.Data,
+	}, nil
+}
+
+// Producer publishes messages to Kafka. It is satisfied by *kafka.Client.
+type Producer interface {
+	PublishMessage(ctx context.Context, message *kafka.Message) error
+}
+
+// Result is the outcome of publishing one event
+type Result struct {
+	Index   int    `json:"index"`
+	EventID string `json:"event_id,omitempty"`
+	Topic   string `json:"topic,omitempty"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BatchResult is the outcome of publishing a batch of events
+type BatchResult struct {
+	Total      int      `json:"total_events"`
+	Successful int      `json:"successful_events"`
+	Failed     int      `json:"failed_events"`
+	Results    []Result `json:"results"`
+}
+
+// SchemaGuard checks the data of an event against the schema registered
+// for its type. It is satisfied by *schemas.Guard.
+type SchemaGuard interface {
+	Check(ctx context.Context, eventType string, data map[string]interface{}) error
+}
+
+// Authorizer checks that the caller of ctx may publish to a topic. It is
+// satisfied by *acl.ACL.
+type Authorizer interface {
+	Authorize(ctx context.Context, topic string) error
+}
+
+// Publisher validates event requests and publishes them to Kafka
+type Publisher struct {
+	producer   Producer
+	metrics    *Metrics
+	schemas    SchemaGuard
+	authorizer Authorizer
+}
+
+// NewPublisher creates a publisher writing to producer
+func NewPublisher(producer Producer, metrics *Metrics) *Publisher {
+	return &Publisher{
+		producer: producer,
+		metrics:  metrics,
+	}
+}
+
+// SetSchemaGuard checks the events against the schemas of guard before they
+// are published
+func (p *Publisher) SetSchemaGuard(guard SchemaGuard) {
+	p.schemas = guard
+}
+
+// SetAuthorizer checks that callers may publish to the topics of their
+// events before the events are published
+func (p *Publisher) SetAuthorizer(authorizer Authorizer) {
+	p.authorizer = authorizer
+}
+
+// Publish validates and publishes a single event. Validation failures wrap
+// ErrInvalidEvent; events rejected by the authorizer or the schema guard
+// fail with their error.
+func (p *Publisher) Publish(ctx context.Context, protocol string, req *EventRequest) (*Result, error) {
+	id := req.ID
+	if id == "" {
+		id = fmt.Sprintf("event_%d", time.Now().UnixNano())
+	}
+	return p.publish(ctx, protocol, req, id)
+}
+
+// PublishBatch validates and publishes each event of the batch on its own, so
+// one invalid or failed event doesn't prevent the others from being published
+func (p *Publisher) PublishBatch(ctx context.Context, protocol string, reqs []EventRequest) (*BatchResult, error) {
+	if len(reqs) == 0 {
+		return nil, fmt.Errorf("%w: no events provided", ErrInvalidBatch)
+	}
+	if len(reqs) > MaxBatchSize {
+		return nil, fmt.Errorf("%w: too many events in batch (max %d)", ErrInvalidBatch, MaxBatchSize)
+	}
+	p.metrics.BatchSize.WithLabelValues(protocol).Observe(float64(len(reqs)))
+
+	batch := &BatchResult{
+		Total:   len(reqs),
+		Results: make([]Result, 0, len(reqs)),
+	}
+	for i := range reqs {
+		id := reqs[i].ID
+		if id == "" {
+			id = fmt.Sprintf("batch_event_%d_%d", time.Now().UnixNano(), i)
+		}
+		result, err := p.publish(ctx, protocol, &reqs[i], id)
+		if err != nil {
+			result = &Result{EventID: result.EventID, Status: "failed", Error: err.Error()}
+			batch.Failed++
+		} else {
+			batch.Successful++
+		}
+		result.Index = i
+		batch.Results = append(batch.Results, *result)
+	}
+
+	return batch, nil
+}
+
+// Check validates req and checks it against the authorizer and the schema
+// guard without publishing it, for events published later
+func (p *Publisher) Check(ctx context.Context, protocol string, req *EventRequest) error {
+	scheduled := *req
+	scheduled.DeliverAt = nil
+	return p.check(ctx, protocol, &scheduled)
+}
+
+// check validates req and checks it against the authorizer and the schema
+// guard. Events scheduled for later are only published by the scheduler.
+func (p *Publisher) check(ctx context.Context, protocol string, req *EventRequest) error {
+	if req.DeliverAt != nil {
+		p.metrics.EventsPublished.WithLabelValues(protocol, "invalid").Inc()
+		return fmt.Errorf("%w: deliver_at is only accepted by POST /events", ErrInvalidEvent)
+	}
+	if err := req.Validate(); err != nil {
+		p.metrics.EventsPublished.WithLabelValues(protocol, "invalid").Inc()
+		return err
+	}
+	if p.authorizer != nil {
+		if err := p.authorizer.Authorize(ctx, TopicOf(req)); err != nil {
+			p.metrics.EventsPublished.WithLabelValues(protocol, "forbidden").Inc()
+			return err
+		}
+	}
+	if p.schemas != nil {
+		data, err := req.DataMap()
+		if err != nil {
+			p.metrics.EventsPublished.WithLabelValues(protocol, "invalid").Inc()
+			return err
+		}
+		if err := p.schemas.Check(ctx, req.EventType, data); err != nil {
+			p.metrics.EventsPublished.WithLabelValues(protocol, "incompatible").Inc()
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Publisher) publish(ctx context.Context, protocol string, req *EventRequest, id string) (*Result, error) {
+	if err := p.check(ctx, protocol, req); err != nil {
+		return &Result{}, err
+	}
+
+	message := NewMessage(req, id)
+
+	start := time.Now()
+	err := p.producer.PublishMessage(ctx, message)
+	p.metrics.PublishDuration.WithLabelValues(protocol).Observe(time.Since(start).Seconds())
+	if errors.Is(err, kafka.ErrBackpressure) {
+		p.metrics.EventsPublished.WithLabelValues(protocol, "rejected").Inc()
+		return &Result{EventID: message.ID}, err
+	}
+	if err != nil {
+		p.metrics.EventsPublished.WithLabelValues(protocol, "failed").Inc()
+		return &Result{EventID: message.ID}, err
+	}
+	p.metrics.EventsPublished.WithLabelValues(protocol, "published").Inc()
+
+	return &Result{
+		EventID: message.ID,
+		Topic:   message.Topic,
+		Status:  "published",
+	}, nil
+}
+
+// NewMessage converts a validated request to the Kafka message published for it
+func NewMessage(req *EventRequest, id string) *kafka.Message {
+	message := &kafka.Message{
+		ID:        id,
+		EventType: req.EventType,
+		Source:    req.Source,
+		Subject:   req.Subject,
+		Data:      req.payload(),
+		Topic:     TopicOf(req),
+		Key:       req.Key,
+		Headers:   req.Headers,
+		Metadata: kafka.MessageMetadata{
+			Timestamp:   time.Now(),
+			Version:     "1.0",
+			ContentType: "application/json",
+			Encoding:    "utf-8",
+		},
+	}
+
+	if message.Headers == nil {
+		message.Headers = make(map[string]string)
+	}
+
+	return message
+}
+
+// TopicOf returns the topic req is published to, app.<event type> unless it
+// names one
+func TopicOf(req *EventRequest) string {
+	if req.Topic != "" {
+		return req.Topic
+	}
+	return fmt.Sprintf("app.%s", req.EventType)
+}
+
+// Metrics contains the Prometheus metrics of the publishing path. Both
+// protocols record into the same collectors, labeled by protocol.
+type Metrics struct {
+	EventsPublished *prometheus.CounterVec
+	PublishDuration *prometheus.HistogramVec
+	BatchSize       *prometheus.HistogramVec
+	// QueueDepth is the number of events waiting in the async publish queue
+	QueueDepth prometheus.Gauge
+}
+
+// NewMetrics creates the publishing metrics and registers them with reg
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		EventsPublished: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "eventbus_events_published_total",
+			Help: "Total number of events received for publishing, by protocol and outcome",
+		}, []string{"protocol", "status"}),
+		PublishDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "eventbus_event_publish_duration_seconds",
+			Help:    "Duration of publishing a single event to Kafka",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"protocol"}),
+		BatchSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "eventbus_event_batch_size",
+			Help:    "Number of events per batch publish request",
+			Buckets: []float64{1, 5, 10, 50, 100, 250, 500, 1000},
+		}, []string{"protocol"}),
+		QueueDepth: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "eventbus_publish_queue_depth",
+			Help: "Number of events waiting in the async publish queue",
+		}),
+	}
+
+	reg.MustRegister(m.EventsPublished, m.PublishDuration, m.BatchSize, m.QueueDepth)
+	return m
+}
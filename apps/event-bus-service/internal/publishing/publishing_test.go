@@ -0,0 +1,178 @@
+package publishing
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+)
+
+func TestDecodeEventRequest(t *testing.T) {
+	req, err := DecodeEventRequest([]byte(`{"event_type":"form.created","source":"form-service","data":{"id":"f-1"}}`))
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if req.EventType != "form.created" || req.Source != "form-service" || string(req.RawData) != `{"id":"f-1"}` || req.ID != "" {
+		t.Errorf("unexpected request: %+v", req)
+	}
+	if data, err := req.DataMap(); err != nil || data["id"] != "f-1" {
+		t.Errorf("DataMap() = %v, %v", data, err)
+	}
+
+	req, err = DecodeEventRequest([]byte(`{
+		"specversion": "1.0",
+		"id": "ce-1",
+		"source": "partner-app",
+		"type": "partner.sync",
+		"subject": "account-7",
+		"data": {"accounts": 3}
+	}`))
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if req.ID != "ce-1" || req.EventType != "partner.sync" || req.Source != "partner-app" || req.Subject != "account-7" || string(req.RawData) != `{"accounts": 3}` {
+		t.Errorf("unexpected request from CloudEvent: %+v", req)
+	}
+
+	req, err = DecodeEventRequest([]byte(`{"event_type":"form.reminder.due","source":"form-service","data":{},"deliver_at":"2026-10-16T09:15:00Z"}`))
+	if err != nil || req.DeliverAt == nil || !req.DeliverAt.Equal(time.Date(2026, 10, 16, 9, 15, 0, 0, time.UTC)) {
+		t.Errorf("deliver_at of %+v (%v)", req, err)
+	}
+
+	for _, body := range []string{
+		`{"specversion":"1.0","id":"ce-1","source":"partner-app"}`,
+		`{"specversion":"1.0","id":"ce-1","source":"partner-app","type":"partner.sync","data":[1,2]}`,
+		`{"event_type":`,
+	} {
+		if _, err := DecodeEventRequest([]byte(body)); !errors.Is(err, ErrInvalidEvent) {
+			t.Errorf("expected ErrInvalidEvent for %s, got %v", body, err)
+		}
+	}
+
+	// The raw data must be an object
+	for body, want := range map[string]string{
+		`{"event_type":"form.created","source":"form-service"}`:              "data is required",
+		`{"event_type":"form.created","source":"form-service","data":null}`:  "data is required",
+		`{"event_type":"form.created","source":"form-service","data":[1,2]}`: "data must be a JSON object",
+	} {
+		req, err := DecodeEventRequest([]byte(body))
+		if err != nil {
+			t.Fatalf("decode failed: %v", err)
+		}
+		if err := req.Validate(); !errors.Is(err, ErrInvalidEvent) || !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() of %s = %v, want %q", body, err, want)
+		}
+	}
+}
+
+// rejectingGuard rejects the events of one type
+type rejectingGuard string
+
+var errRejected = errors.New("rejected by schema")
+
+func (g rejectingGuard) Check(ctx context.Context, eventType string, data map[string]interface{}) error {
+	if eventType == string(g) {
+		return errRejected
+	}
+	return nil
+}
+
+func TestSchemaGuard(t *testing.T) {
+	producer := &gatedProducer{gate: make(chan struct{})}
+	close(producer.gate)
+	metrics := NewMetrics(prometheus.NewRegistry())
+	publisher := NewPublisher(producer, metrics)
+	publisher.SetSchemaGuard(rejectingGuard("form.deleted"))
+	queue := NewQueue(publisher, 10, 1, 0, zap.NewNop())
+
+	event := func(eventType string) *EventRequest {
+		return &EventRequest{EventType: eventType, Source: "form-service", Data: map[string]interface{}{}}
+	}
+	if _, err := publisher.Publish(context.Background(), ProtocolHTTP, event("form.deleted")); !errors.Is(err, errRejected) {
+		t.Errorf("Publish() err = %v, want the guard error", err)
+	}
+	if _, err := queue.Enqueue(context.Background(), ProtocolHTTP, event("form.deleted")); !errors.Is(err, errRejected) {
+		t.Errorf("Enqueue() err = %v, want the guard error", err)
+	}
+	if _, err := publisher.Publish(context.Background(), ProtocolHTTP, event("form.created")); err != nil {
+		t.Errorf("Publish() of an accepted event: %v", err)
+	}
+
+	if got := testutil.ToFloat64(metrics.EventsPublished.WithLabelValues(ProtocolHTTP, "incompatible")); got != 2 {
+		t.Errorf("incompatible events = %v, want 2", got)
+	}
+	if len(producer.sent) != 1 {
+		t.Errorf("sent %d events, want 1", len(producer.sent))
+	}
+}
+
+var errForbidden = errors.New("forbidden")
+
+// topicAuthorizer forbids publishing to one topic
+type topicAuthorizer string
+
+func (a topicAuthorizer) Authorize(_ context.Context, topic string) error {
+	if topic == string(a) {
+		return errForbidden
+	}
+	return nil
+}
+
+func TestAuthorizer(t *testing.T) {
+	producer := &gatedProducer{gate: make(chan struct{})}
+	close(producer.gate)
+	metrics := NewMetrics(prometheus.NewRegistry())
+	publisher := NewPublisher(producer, metrics)
+	publisher.SetAuthorizer(topicAuthorizer("app.form.deleted"))
+	queue := NewQueue(publisher, 10, 1, 0, zap.NewNop())
+
+	event := func(eventType, topic string) *EventRequest {
+		return &EventRequest{EventType: eventType, Source: "form-service", Topic: topic, Data: map[string]interface{}{}}
+	}
+	// The default topic of the event type is authorized
+	if _, err := publisher.Publish(context.Background(), ProtocolHTTP, event("form.deleted", "")); !errors.Is(err, errForbidden) {
+		t.Errorf("Publish() err = %v, want the authorizer error", err)
+	}
+	if _, err := queue.Enqueue(context.Background(), ProtocolHTTP, event("form.created", "app.form.deleted")); !errors.Is(err, errForbidden) {
+		t.Errorf("Enqueue() err = %v, want the authorizer error", err)
+	}
+	batch, err := publisher.PublishBatch(context.Background(), ProtocolHTTP, []EventRequest{*event("form.deleted", ""), *event("form.created", "")})
+	if err != nil || batch.Successful != 1 || batch.Failed != 1 {
+		t.Errorf("PublishBatch() = %+v, %v; want the forbidden event to fail alone", batch, err)
+	}
+
+	if got := testutil.ToFloat64(metrics.EventsPublished.WithLabelValues(ProtocolHTTP, "forbidden")); got != 3 {
+		t.Errorf("forbidden events = %v, want 3", got)
+	}
+	if len(producer.sent) != 1 {
+		t.Errorf("sent %d events, want 1", len(producer.sent))
+	}
+}
+
+func TestScheduledEventsAreNotPublished(t *testing.T) {
+	producer := &gatedProducer{gate: make(chan struct{})}
+	close(producer.gate)
+	publisher := NewPublisher(producer, NewMetrics(prometheus.NewRegistry()))
+	queue := NewQueue(publisher, 10, 1, 0, zap.NewNop())
+
+	deliverAt := time.Now().Add(time.Hour)
+	req := &EventRequest{EventType: "form.reminder.due", Source: "form-service", Data: map[string]interface{}{}, DeliverAt: &deliverAt}
+	if _, err := publisher.Publish(context.Background(), ProtocolGRPC, req); !errors.Is(err, ErrInvalidEvent) {
+		t.Errorf("Publish() err = %v, want ErrInvalidEvent", err)
+	}
+	if _, err := queue.Enqueue(context.Background(), ProtocolHTTP, req); !errors.Is(err, ErrInvalidEvent) {
+		t.Errorf("Enqueue() err = %v, want ErrInvalidEvent", err)
+	}
+	// Checked for scheduling, the deliver_at is fine
+	if err := publisher.Check(context.Background(), ProtocolHTTP, req); err != nil {
+		t.Errorf("Check() = %v", err)
+	}
+	if req.DeliverAt == nil || len(producer.sent) != 0 {
+		t.Errorf("sent %d events", len(producer.sent))
+	}
+}
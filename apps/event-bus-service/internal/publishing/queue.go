@@ -0,0 +1,170 @@
+package publishing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+)
+
+// ErrQueueFull is returned by Enqueue when the queue is full. It wraps
+// kafka.ErrBackpressure, so callers handle both alike.
+var ErrQueueFull = fmt.Errorf("%w: the publish queue is full", kafka.ErrBackpressure)
+
+// ErrQueueClosed is returned by Enqueue once the queue is stopping
+var ErrQueueClosed = errors.New("the publish queue is closed")
+
+// queued is an event waiting in the queue
+type queued struct {
+	protocol string
+	message  *kafka.Message
+}
+
+// Queue publishes events in the background, for the async publish mode.
+// Enqueue checks an event and queues it without waiting on the brokers;
+// workers drain the queue. Messages refused for backpressure are retried.
+type Queue struct {
+	publisher  *Publisher
+	workers    int
+	retryAfter time.Duration
+	logger     *zap.Logger
+
+	mu     sync.RWMutex
+	closed bool
+	events chan queued
+
+	// ctx is cancelled when Stop gives up on draining the queue
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewQueue creates a queue of size events published with publisher by
+// workers. Messages refused for backpressure are retried after retryAfter.
+func NewQueue(publisher *Publisher, size, workers int, retryAfter time.Duration, logger *zap.Logger) *Queue {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Queue{
+		publisher:  publisher,
+		workers:    workers,
+		retryAfter: retryAfter,
+		logger:     logger,
+		events:     make(chan queued, size),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Start starts the workers draining the queue
+func (q *Queue) Start() {
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go q.drain()
+	}
+}
+
+// Enqueue validates an event and queues it for publishing. Validation
+// failures wrap ErrInvalidEvent; events rejected by the schema guard of the
+// publisher fail with its error; a full queue is ErrQueueFull.
+func (q *Queue) Enqueue(ctx context.Context, protocol string, req *EventRequest) (*Result, error) {
+	if err := q.publisher.check(ctx, protocol, req); err != nil {
+		return &Result{}, err
+	}
+	id := req.ID
+	if id == "" {
+		id = fmt.Sprintf("event_%d", time.Now().UnixNano())
+	}
+	message := NewMessage(req, id)
+
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return &Result{EventID: id}, ErrQueueClosed
+	}
+	select {
+	case q.events <- queued{protocol: protocol, message: message}:
+	default:
+		q.publisher.metrics.EventsPublished.WithLabelValues(protocol, "rejected").Inc()
+		return &Result{EventID: id}, ErrQueueFull
+	}
+	q.publisher.metrics.QueueDepth.Inc()
+	q.publisher.metrics.EventsPublished.WithLabelValues(protocol, "queued").Inc()
+
+	return &Result{
+		EventID: message.ID,
+		Topic:   message.Topic,
+		Status:  "queued",
+	}, nil
+}
+
+// Stop stops accepting events and waits for the workers to publish those
+// queued. When ctx is done first, the remaining events are dropped.
+func (q *Queue) Stop(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.events)
+	}
+	q.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		remaining := len(q.events)
+		q.cancel()
+		<-drained
+		return fmt.Errorf("%d queued events were dropped: %w", remaining, ctx.Err())
+	}
+}
+
+// drain publishes queued events until the queue is closed and empty
+func (q *Queue) drain() {
+	defer q.wg.Done()
+	for event := range q.events {
+		q.publisher.metrics.QueueDepth.Dec()
+		q.publish(event)
+	}
+}
+
+// publish publishes a queued event, retrying while the producer refuses it
+// for backpressure
+func (q *Queue) publish(event queued) {
+	metrics := q.publisher.metrics
+	for {
+		if err := q.ctx.Err(); err != nil {
+			metrics.EventsPublished.WithLabelValues(event.protocol, "failed").Inc()
+			return
+		}
+
+		start := time.Now()
+		err := q.publisher.producer.PublishMessage(q.ctx, event.message)
+		metrics.PublishDuration.WithLabelValues(event.protocol).Observe(time.Since(start).Seconds())
+		if errors.Is(err, kafka.ErrBackpressure) {
+			select {
+			case <-time.After(q.retryAfter):
+			case <-q.ctx.Done():
+			}
+			continue
+		}
+		if err != nil {
+			metrics.EventsPublished.WithLabelValues(event.protocol, "failed").Inc()
+			q.logger.Error("Failed to publish queued event",
+				zap.String("event_id", event.message.ID),
+				zap.String("topic", event.message.Topic),
+				zap.Error(err))
+			return
+		}
+		metrics.EventsPublished.WithLabelValues(event.protocol, "published").Inc()
+		return
+	}
+}
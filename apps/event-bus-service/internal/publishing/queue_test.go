@@ -0,0 +1,114 @@
+package publishing
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+)
+
+// gatedProducer refuses the first messages for backpressure, then blocks
+// until the gate opens and records the messages, or gives up with ctx
+type gatedProducer struct {
+	gate    chan struct{}
+	refuse  int
+	mu      sync.Mutex
+	refused int
+	sent    []string
+}
+
+func (p *gatedProducer) PublishMessage(ctx context.Context, message *kafka.Message) error {
+	p.mu.Lock()
+	if p.refused < p.refuse {
+		p.refused++
+		p.mu.Unlock()
+		return kafka.ErrBackpressure
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-p.gate:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sent = append(p.sent, message.ID)
+	return nil
+}
+
+func TestQueue(t *testing.T) {
+	producer := &gatedProducer{gate: make(chan struct{}), refuse: 2}
+	metrics := NewMetrics(prometheus.NewRegistry())
+	queue := NewQueue(NewPublisher(producer, metrics), 2, 1, time.Millisecond, zap.NewNop())
+
+	event := func(id string) *EventRequest {
+		return &EventRequest{ID: id, EventType: "form.created", Source: "form-service", Data: map[string]interface{}{}}
+	}
+	if _, err := queue.Enqueue(context.Background(), ProtocolHTTP, &EventRequest{EventType: "form.created"}); !errors.Is(err, ErrInvalidEvent) {
+		t.Errorf("invalid event: err = %v, want ErrInvalidEvent", err)
+	}
+
+	// Until the workers start, the queue holds its size
+	for _, id := range []string{"e-1", "e-2"} {
+		result, err := queue.Enqueue(context.Background(), ProtocolHTTP, event(id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.EventID != id || result.Status != "queued" {
+			t.Errorf("result = %+v, want %s queued", result, id)
+		}
+	}
+	if _, err := queue.Enqueue(context.Background(), ProtocolHTTP, event("e-3")); !errors.Is(err, kafka.ErrBackpressure) {
+		t.Errorf("full queue: err = %v, want ErrBackpressure", err)
+	}
+	if depth := testutil.ToFloat64(metrics.QueueDepth); depth != 2 {
+		t.Errorf("queue depth = %v, want 2", depth)
+	}
+
+	// Refused for backpressure, the first event is retried
+	queue.Start()
+	close(producer.gate)
+	if err := queue.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(producer.sent) != 2 || producer.sent[0] != "e-1" || producer.sent[1] != "e-2" {
+		t.Errorf("sent %v, want e-1 and e-2", producer.sent)
+	}
+	if published := testutil.ToFloat64(metrics.EventsPublished.WithLabelValues(ProtocolHTTP, "published")); published != 2 {
+		t.Errorf("published = %v, want 2", published)
+	}
+	if _, err := queue.Enqueue(context.Background(), ProtocolHTTP, event("e-4")); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("stopped queue: err = %v, want ErrQueueClosed", err)
+	}
+}
+
+func TestQueueStopDropsOnTimeout(t *testing.T) {
+	producer := &gatedProducer{gate: make(chan struct{})}
+	metrics := NewMetrics(prometheus.NewRegistry())
+	queue := NewQueue(NewPublisher(producer, metrics), 10, 1, time.Millisecond, zap.NewNop())
+	queue.Start()
+	for i := 0; i < 3; i++ {
+		if _, err := queue.Enqueue(context.Background(), ProtocolHTTP, &EventRequest{EventType: "form.created", Source: "form-service", Data: map[string]interface{}{}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The brokers never answer: the event in flight fails and the others
+	// are dropped
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := queue.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want the deadline exceeded", err)
+	}
+	if failed := testutil.ToFloat64(metrics.EventsPublished.WithLabelValues(ProtocolHTTP, "failed")); failed != 3 || len(producer.sent) != 0 {
+		t.Errorf("failed = %v, sent %v; want all 3 failed", failed, producer.sent)
+	}
+}
@@ -0,0 +1,87 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+)
+
+// leaderLockKey is the advisory lock held by the replica delivering the
+// scheduled events
+const leaderLockKey = 0x7363686564 // "sched"
+
+// PostgresElector elects the leader with a session advisory lock, held on a
+// connection of its own. PostgreSQL releases the lock when the session
+// ends, so a replica that dies or loses its connection hands the
+// leadership over to the next replica to campaign.
+type PostgresElector struct {
+	db *sql.DB
+
+	mu   sync.Mutex
+	conn *sql.Conn
+}
+
+// NewPostgresElector creates an elector locking through db
+func NewPostgresElector(db *sql.DB) *PostgresElector {
+	return &PostgresElector{db: db}
+}
+
+// Lead reports whether this replica holds the lock, trying to take it when
+// it doesn't
+func (e *PostgresElector) Lead(ctx context.Context) (bool, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.conn != nil {
+		if err := e.conn.PingContext(ctx); err == nil {
+			return true, nil
+		}
+		// The session, and the lock with it, is gone
+		discard(e.conn)
+		e.conn = nil
+	}
+
+	conn, err := e.db.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get a connection: %w", err)
+	}
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", leaderLockKey).Scan(&acquired); err != nil {
+		conn.Close()
+		return false, fmt.Errorf("failed to take the scheduler lock: %w", err)
+	}
+	if !acquired {
+		conn.Close()
+		return false, nil
+	}
+	e.conn = conn
+	return true, nil
+}
+
+// Resign releases the lock, if held
+func (e *PostgresElector) Resign(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.conn == nil {
+		return nil
+	}
+	_, err := e.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", leaderLockKey)
+	// Ending the session releases the lock whether or not the unlock went
+	// through
+	discard(e.conn)
+	e.conn = nil
+	if err != nil {
+		return fmt.Errorf("failed to release the scheduler lock: %w", err)
+	}
+	return nil
+}
+
+// discard closes the connection rather than returning it to the pool, so
+// its session ends along with the locks it holds
+func discard(conn *sql.Conn) {
+	conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+	conn.Close()
+}
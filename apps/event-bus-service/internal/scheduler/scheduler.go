@@ -0,0 +1,465 @@
+// Package scheduler delivers events published for later. POST /events with a
+// deliver_at stores the event in the scheduled_events table of the event
+// store database; the replica elected leader polls the table and publishes
+// the events once due, recording the offset each was written at.
+//
+// Delivery is at least once: a leader dying between publishing an event and
+// recording it leaves the event to the next leader, which publishes it
+// again with the same ID. Scheduled events are published on their own, so
+// they are not ordered relative to the events published immediately.
+package scheduler
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/publishing"
+)
+
+// Statuses of scheduled events
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusCancelled = "cancelled"
+)
+
+var (
+	// ErrNotFound is returned for scheduled events that don't exist
+	ErrNotFound = errors.New("scheduled event not found")
+	// ErrDuplicate is returned when an event with the ID is already scheduled
+	ErrDuplicate = errors.New("an event with this ID is already scheduled")
+	// ErrNotPending is returned when cancelling events already delivered or
+	// cancelled
+	ErrNotPending = errors.New("scheduled event is no longer pending")
+	// ErrDelivering is returned when cancelling events the leader is
+	// publishing
+	ErrDelivering = errors.New("scheduled event is being delivered")
+	// ErrClaimLost is returned when recording the delivery of an event whose
+	// claim lapsed and was taken over
+	ErrClaimLost = errors.New("claim on scheduled event lost")
+	// ErrInvalidFilter is returned for listings that can't be run
+	ErrInvalidFilter = errors.New("invalid scheduled events filter")
+)
+
+// Event is an event scheduled for delivery
+type Event struct {
+	ID        string            `json:"id"`
+	EventType string            `json:"event_type"`
+	Source    string            `json:"source"`
+	Subject   string            `json:"subject,omitempty"`
+	Topic     string            `json:"topic"`
+	Key       string            `json:"key,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Data      json.RawMessage   `json:"data" swaggertype:"object"`
+	DeliverAt time.Time         `json:"deliver_at"`
+	Status    string            `json:"status" example:"pending"`
+	CreatedAt time.Time         `json:"created_at"`
+	// NextAttemptAt is when the event is next published: DeliverAt, or
+	// later after failed attempts
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	Attempts      int       `json:"attempts"`
+	LastError     string    `json:"last_error,omitempty"`
+	// DeliveredAt, Partition and Offset record the delivery
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+	Partition   *int32     `json:"partition,omitempty"`
+	Offset      *int64     `json:"offset,omitempty"`
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+
+	// ClaimedBy is the replica publishing the event, until ClaimedUntil
+	ClaimedBy    string     `json:"-"`
+	ClaimedUntil *time.Time `json:"-"`
+}
+
+// claimed reports whether a replica holds a live claim on the event at now
+func (e *Event) claimed(now time.Time) bool {
+	return e.ClaimedUntil != nil && now.Before(*e.ClaimedUntil)
+}
+
+// message is the Kafka message the event is published as
+func (e *Event) message() *kafka.Message {
+	return publishing.NewMessage(&publishing.EventRequest{
+		EventType: e.EventType,
+		Source:    e.Source,
+		Subject:   e.Subject,
+		RawData:   e.Data,
+		Headers:   e.Headers,
+		Topic:     e.Topic,
+		Key:       e.Key,
+	}, e.ID)
+}
+
+// Filter selects the pending events listed
+type Filter struct {
+	Topic     string
+	EventType string
+	Source    string
+	// From and To bound the delivery times
+	From *time.Time
+	To   *time.Time
+	// Limit is 100 by default and at most 1000
+	Limit int
+	// After is the next_cursor of the previous page
+	After string
+
+	afterTime time.Time
+	afterID   string
+}
+
+// Page is a page of pending events, by delivery time
+type Page struct {
+	Events []Event `json:"events"`
+	// NextCursor is passed as after for the following page, empty on the last
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// normalize applies the default limit, checks the filter and decodes its
+// cursor
+func (f Filter) normalize() (Filter, error) {
+	if f.Limit == 0 {
+		f.Limit = 100
+	}
+	if f.Limit < 0 || f.Limit > 1000 {
+		return f, fmt.Errorf("%w: limit must be between 1 and 1000", ErrInvalidFilter)
+	}
+	if f.From != nil && f.To != nil && f.To.Before(*f.From) {
+		return f, fmt.Errorf("%w: to is before from", ErrInvalidFilter)
+	}
+	if f.After != "" {
+		decoded, err := base64.RawURLEncoding.DecodeString(f.After)
+		at, id, ok := strings.Cut(string(decoded), "|")
+		if err != nil || !ok || id == "" {
+			return f, fmt.Errorf("%w: invalid cursor", ErrInvalidFilter)
+		}
+		if f.afterTime, err = time.Parse(time.RFC3339Nano, at); err != nil {
+			return f, fmt.Errorf("%w: invalid cursor", ErrInvalidFilter)
+		}
+		f.afterID = id
+	}
+	return f, nil
+}
+
+// cursor is the cursor of the page ending at event
+func cursor(event *Event) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(event.DeliverAt.UTC().Format(time.RFC3339Nano) + "|" + event.ID))
+}
+
+// Backlog counts the pending events
+type Backlog struct {
+	Pending int64
+	// Due counts the pending events whose next attempt has come
+	Due int64
+}
+
+// Store keeps the scheduled events. Claims, cancellations and deliveries of
+// an event are atomic, so each pending event is either cancelled or
+// delivered, never both.
+type Store interface {
+	// Schedule stores a pending event, failing with ErrDuplicate when its
+	// ID is taken
+	Schedule(ctx context.Context, event *Event) error
+	Get(ctx context.Context, id string) (*Event, error)
+	// List returns a page of the pending events matching filter
+	List(ctx context.Context, filter Filter) (*Page, error)
+	// Cancel cancels a pending event not claimed at now. It fails with
+	// ErrNotFound, ErrNotPending or ErrDelivering.
+	Cancel(ctx context.Context, id string, now time.Time) (*Event, error)
+	// Claim claims up to limit pending events due at now and not claimed
+	// by another live claim, for owner until now plus lease
+	Claim(ctx context.Context, owner string, now time.Time, lease time.Duration, limit int) ([]Event, error)
+	// MarkDelivered records the delivery of an event claimed by owner,
+	// failing with ErrClaimLost when the claim was taken over
+	MarkDelivered(ctx context.Context, id, owner string, partition int32, offset int64, at time.Time) error
+	// Release gives up the claim of owner on an event that failed to
+	// publish, to be tried again at retryAt
+	Release(ctx context.Context, id, owner string, retryAt time.Time, cause string) error
+	Backlog(ctx context.Context, now time.Time) (Backlog, error)
+}
+
+// Elector elects the replica delivering the scheduled events
+type Elector interface {
+	// Lead reports whether this replica leads, campaigning when it doesn't
+	Lead(ctx context.Context) (bool, error)
+	// Resign gives up the leadership
+	Resign(ctx context.Context) error
+}
+
+// Producer publishes messages to Kafka. It is satisfied by *kafka.Client.
+type Producer interface {
+	SendMessage(ctx context.Context, message *kafka.Message) (int32, int64, error)
+}
+
+// Scheduler schedules events and delivers them when due, while its replica
+// leads
+type Scheduler struct {
+	cfg      config.ScheduledEventsConfig
+	store    Store
+	elector  Elector
+	producer Producer
+	metrics  *Metrics
+	logger   *zap.Logger
+	// owner identifies the claims of this replica
+	owner string
+	now   func() time.Time
+
+	mu      sync.Mutex
+	leading bool
+}
+
+// NewScheduler creates a scheduler keeping events in store and publishing
+// them to producer while elector elects its replica
+func NewScheduler(cfg config.ScheduledEventsConfig, store Store, elector Elector, producer Producer, metrics *Metrics, logger *zap.Logger) *Scheduler {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	hostname, _ := os.Hostname()
+	return &Scheduler{
+		cfg:      cfg,
+		store:    store,
+		elector:  elector,
+		producer: producer,
+		metrics:  metrics,
+		logger:   logger,
+		owner:    fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), time.Now().UnixNano()),
+		now:      time.Now,
+	}
+}
+
+// Schedule stores req for delivery at its DeliverAt. The request must have
+// been checked by the publisher; deliveries beyond the max delay wrap
+// publishing.ErrInvalidEvent.
+func (s *Scheduler) Schedule(ctx context.Context, req *publishing.EventRequest) (*Event, error) {
+	now := s.now().UTC()
+	if req.DeliverAt == nil {
+		return nil, fmt.Errorf("%w: deliver_at is required", publishing.ErrInvalidEvent)
+	}
+	if req.DeliverAt.After(now.Add(s.cfg.MaxDelay)) {
+		return nil, fmt.Errorf("%w: deliver_at is more than %s ahead", publishing.ErrInvalidEvent, s.cfg.MaxDelay)
+	}
+
+	data := req.RawData
+	if data == nil {
+		encoded, err := json.Marshal(req.Data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", publishing.ErrInvalidEvent, err)
+		}
+		data = encoded
+	}
+	event := &Event{
+		ID:        req.ID,
+		EventType: req.EventType,
+		Source:    req.Source,
+		Subject:   req.Subject,
+		Topic:     publishing.TopicOf(req),
+		Key:       req.Key,
+		Headers:   req.Headers,
+		// RawData aliases the request body, which is reused
+		Data: append(json.RawMessage(nil), data...),
+		// PostgreSQL keeps microseconds
+		DeliverAt: req.DeliverAt.UTC().Truncate(time.Microsecond),
+		Status:    StatusPending,
+		CreatedAt: now.Truncate(time.Microsecond),
+	}
+	if event.ID == "" {
+		event.ID = fmt.Sprintf("scheduled_event_%d", now.UnixNano())
+	}
+	event.NextAttemptAt = event.DeliverAt
+
+	if err := s.store.Schedule(ctx, event); err != nil {
+		return nil, err
+	}
+	s.metrics.Events.WithLabelValues("scheduled").Inc()
+	return event, nil
+}
+
+// Get returns a scheduled event
+func (s *Scheduler) Get(ctx context.Context, id string) (*Event, error) {
+	return s.store.Get(ctx, id)
+}
+
+// List returns a page of the pending events matching filter
+func (s *Scheduler) List(ctx context.Context, filter Filter) (*Page, error) {
+	filter, err := filter.normalize()
+	if err != nil {
+		return nil, err
+	}
+	return s.store.List(ctx, filter)
+}
+
+// Cancel cancels a pending event. Events being published can't be cancelled.
+func (s *Scheduler) Cancel(ctx context.Context, id string) (*Event, error) {
+	event, err := s.store.Cancel(ctx, id, s.now())
+	if err != nil {
+		return nil, err
+	}
+	s.metrics.Events.WithLabelValues("cancelled").Inc()
+	return event, nil
+}
+
+// Run delivers the due events every poll interval until ctx is done, then
+// resigns the leadership. Polls are repeated at once while batches are full.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			claimed, err := s.Poll(ctx)
+			if err != nil && ctx.Err() == nil {
+				s.logger.Error("Failed to deliver scheduled events", zap.Error(err))
+			}
+			if err != nil || claimed < s.cfg.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			resignCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := s.elector.Resign(resignCtx); err != nil {
+				s.logger.Warn("Failed to resign the scheduler leadership", zap.Error(err))
+			}
+			cancel()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll claims the due events and publishes them, when this replica leads,
+// and returns the number claimed. Events failing to publish are retried
+// after the retry backoff.
+func (s *Scheduler) Poll(ctx context.Context) (int, error) {
+	leading, err := s.elector.Lead(ctx)
+	if err != nil {
+		s.setLeading(false)
+		return 0, fmt.Errorf("failed to campaign for the scheduler leadership: %w", err)
+	}
+	s.setLeading(leading)
+	if !leading {
+		return 0, nil
+	}
+
+	events, err := s.store.Claim(ctx, s.owner, s.now(), s.cfg.ClaimTimeout, s.cfg.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim due events: %w", err)
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].NextAttemptAt.Before(events[j].NextAttemptAt) })
+	for i := range events {
+		// Claims left on shutdown lapse and the next leader takes them over
+		if ctx.Err() != nil {
+			return len(events), ctx.Err()
+		}
+		s.deliver(ctx, &events[i])
+	}
+
+	if backlog, err := s.store.Backlog(ctx, s.now()); err != nil {
+		s.logger.Warn("Failed to count the scheduled events", zap.Error(err))
+	} else {
+		s.metrics.Backlog.WithLabelValues("pending").Set(float64(backlog.Pending))
+		s.metrics.Backlog.WithLabelValues("due").Set(float64(backlog.Due))
+	}
+	return len(events), nil
+}
+
+// deliver publishes a claimed event and records its delivery
+func (s *Scheduler) deliver(ctx context.Context, event *Event) {
+	// A lapsed claim may have been cancelled or taken over since
+	if !event.claimed(s.now()) {
+		s.metrics.Events.WithLabelValues("claim_lapsed").Inc()
+		return
+	}
+
+	partition, offset, err := s.producer.SendMessage(ctx, event.message())
+	if err != nil {
+		s.metrics.Events.WithLabelValues("failed").Inc()
+		s.logger.Warn("Failed to publish scheduled event",
+			zap.String("event_id", event.ID), zap.String("topic", event.Topic), zap.Error(err))
+		if err := s.store.Release(ctx, event.ID, s.owner, s.now().Add(s.cfg.RetryBackoff), err.Error()); err != nil {
+			s.logger.Warn("Failed to release scheduled event", zap.String("event_id", event.ID), zap.Error(err))
+		}
+		return
+	}
+
+	deliveredAt := s.now()
+	if err := s.store.MarkDelivered(ctx, event.ID, s.owner, partition, offset, deliveredAt); err != nil {
+		// Published but not recorded, the event is delivered again once its
+		// claim lapses
+		s.metrics.Events.WithLabelValues("unrecorded").Inc()
+		s.logger.Warn("Failed to record the delivery of scheduled event",
+			zap.String("event_id", event.ID), zap.Int32("partition", partition), zap.Int64("offset", offset), zap.Error(err))
+		return
+	}
+	s.metrics.Events.WithLabelValues("delivered").Inc()
+	s.metrics.DeliveryDelay.Observe(deliveredAt.Sub(event.DeliverAt).Seconds())
+}
+
+// Leading reports whether this replica led at its last poll
+func (s *Scheduler) Leading() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.leading
+}
+
+func (s *Scheduler) setLeading(leading bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.leading && !leading {
+		s.logger.Warn("Lost the scheduler leadership")
+		// The backlog is reported by the leader
+		s.metrics.Backlog.Reset()
+	} else if !s.leading && leading {
+		s.logger.Info("Elected to deliver the scheduled events")
+	}
+	s.leading = leading
+	if leading {
+		s.metrics.Leader.Set(1)
+	} else {
+		s.metrics.Leader.Set(0)
+	}
+}
+
+// Metrics contains the Prometheus metrics of the scheduler
+type Metrics struct {
+	Events        *prometheus.CounterVec
+	Backlog       *prometheus.GaugeVec
+	DeliveryDelay prometheus.Histogram
+	Leader        prometheus.Gauge
+}
+
+// NewMetrics creates the scheduler metrics and registers them with reg
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		Events: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "eventbus_scheduled_events_total",
+			Help: "Total number of scheduled events, by what happened to them",
+		}, []string{"status"}),
+		Backlog: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "eventbus_scheduled_events_backlog",
+			Help: "Number of pending scheduled events, and of those due, at the last poll of the leader",
+		}, []string{"state"}),
+		DeliveryDelay: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "eventbus_scheduled_event_delivery_delay_seconds",
+			Help:    "Delay between the deliver_at of scheduled events and their publishing",
+			Buckets: []float64{0.5, 1, 2.5, 5, 10, 30, 60, 300, 900, 3600},
+		}),
+		Leader: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "eventbus_scheduler_leader",
+			Help: "Whether this replica delivers the scheduled events",
+		}),
+	}
+	reg.MustRegister(m.Events, m.Backlog, m.DeliveryDelay, m.Leader)
+	return m
+}
@@ -0,0 +1,661 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/publishing"
+)
+
+// memoryStore keeps scheduled events in memory, with the conditional
+// updates of PostgresStore
+type memoryStore struct {
+	mu     sync.Mutex
+	events map[string]*Event
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{events: make(map[string]*Event)}
+}
+
+func (s *memoryStore) Schedule(_ context.Context, event *Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.events[event.ID]; ok {
+		return ErrDuplicate
+	}
+	stored := *event
+	s.events[event.ID] = &stored
+	return nil
+}
+
+func (s *memoryStore) Get(_ context.Context, id string) (*Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	event, ok := s.events[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	found := *event
+	return &found, nil
+}
+
+func (s *memoryStore) List(_ context.Context, filter Filter) (*Page, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	page := &Page{Events: []Event{}}
+	for _, event := range s.events {
+		if event.Status == StatusPending {
+			page.Events = append(page.Events, *event)
+		}
+	}
+	sort.Slice(page.Events, func(i, j int) bool { return page.Events[i].ID < page.Events[j].ID })
+	return page, nil
+}
+
+func (s *memoryStore) Cancel(_ context.Context, id string, now time.Time) (*Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	event, ok := s.events[id]
+	switch {
+	case !ok:
+		return nil, ErrNotFound
+	case event.Status != StatusPending:
+		return nil, ErrNotPending
+	case event.claimed(now):
+		return nil, ErrDelivering
+	}
+	event.Status = StatusCancelled
+	event.CancelledAt = &now
+	cancelled := *event
+	return &cancelled, nil
+}
+
+func (s *memoryStore) Claim(_ context.Context, owner string, now time.Time, lease time.Duration, limit int) ([]Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	until := now.Add(lease)
+	var claimed []Event
+	for _, event := range s.events {
+		if len(claimed) == limit {
+			break
+		}
+		if event.Status != StatusPending || event.NextAttemptAt.After(now) || event.claimed(now) {
+			continue
+		}
+		event.ClaimedBy, event.ClaimedUntil = owner, &until
+		claimed = append(claimed, *event)
+	}
+	return claimed, nil
+}
+
+func (s *memoryStore) MarkDelivered(_ context.Context, id, owner string, partition int32, offset int64, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	event, ok := s.events[id]
+	if !ok || event.Status != StatusPending || event.ClaimedBy != owner {
+		return ErrClaimLost
+	}
+	event.Status = StatusDelivered
+	event.DeliveredAt, event.Partition, event.Offset = &at, &partition, &offset
+	event.Attempts++
+	event.ClaimedBy, event.ClaimedUntil = "", nil
+	return nil
+}
+
+func (s *memoryStore) Release(_ context.Context, id, owner string, retryAt time.Time, cause string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	event, ok := s.events[id]
+	if !ok || event.Status != StatusPending || event.ClaimedBy != owner {
+		return ErrClaimLost
+	}
+	event.NextAttemptAt = retryAt
+	event.Attempts++
+	event.LastError = cause
+	event.ClaimedBy, event.ClaimedUntil = "", nil
+	return nil
+}
+
+func (s *memoryStore) Backlog(_ context.Context, now time.Time) (Backlog, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var backlog Backlog
+	for _, event := range s.events {
+		if event.Status == StatusPending {
+			backlog.Pending++
+			if !event.NextAttemptAt.After(now) {
+				backlog.Due++
+			}
+		}
+	}
+	return backlog, nil
+}
+
+// memoryLock is an advisory lock shared by the electors of replicas
+type memoryLock struct {
+	mu     sync.Mutex
+	holder string
+}
+
+// drop ends the session of the holder, releasing the lock as PostgreSQL
+// does when the connection of a dead replica closes
+func (l *memoryLock) drop() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.holder = ""
+}
+
+type memoryElector struct {
+	lock *memoryLock
+	name string
+}
+
+func (e *memoryElector) Lead(context.Context) (bool, error) {
+	e.lock.mu.Lock()
+	defer e.lock.mu.Unlock()
+	if e.lock.holder == "" {
+		e.lock.holder = e.name
+	}
+	return e.lock.holder == e.name, nil
+}
+
+func (e *memoryElector) Resign(context.Context) error {
+	e.lock.mu.Lock()
+	defer e.lock.mu.Unlock()
+	if e.lock.holder == e.name {
+		e.lock.holder = ""
+	}
+	return nil
+}
+
+// memoryBroker records the messages published, at increasing offsets
+type memoryBroker struct {
+	mu        sync.Mutex
+	published []*kafka.Message
+}
+
+func (b *memoryBroker) SendMessage(_ context.Context, message *kafka.Message) (int32, int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.published = append(b.published, message)
+	return 0, int64(len(b.published) - 1), nil
+}
+
+// deliveries counts the messages published for each event ID
+func (b *memoryBroker) deliveries() map[string]int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	counts := make(map[string]int)
+	for _, message := range b.published {
+		counts[message.ID]++
+	}
+	return counts
+}
+
+// producerFunc adapts a function to a Producer
+type producerFunc func(ctx context.Context, message *kafka.Message) (int32, int64, error)
+
+func (f producerFunc) SendMessage(ctx context.Context, message *kafka.Message) (int32, int64, error) {
+	return f(ctx, message)
+}
+
+// clock is the time of the schedulers of a test, advanced by hand
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+var testConfig = config.ScheduledEventsConfig{
+	Enabled:      true,
+	PollInterval: time.Second,
+	BatchSize:    100,
+	ClaimTimeout: time.Minute,
+	RetryBackoff: 30 * time.Second,
+	MaxDelay:     24 * time.Hour,
+}
+
+// newTestScheduler creates the scheduler of a replica named name
+func newTestScheduler(name string, store Store, lock *memoryLock, producer Producer, now *clock) *Scheduler {
+	s := NewScheduler(testConfig, store, &memoryElector{lock: lock, name: name}, producer, NewMetrics(prometheus.NewRegistry()), nil)
+	s.owner = name
+	s.now = now.Now
+	return s
+}
+
+// schedule schedules the events of ids for delivery after delay
+func schedule(t *testing.T, s *Scheduler, delay time.Duration, ids ...string) {
+	t.Helper()
+	deliverAt := s.now().Add(delay)
+	for _, id := range ids {
+		_, err := s.Schedule(context.Background(), &publishing.EventRequest{
+			ID:        id,
+			EventType: "form.reminder.due",
+			Source:    "form-service",
+			RawData:   json.RawMessage(`{"form_id":"form-1"}`),
+			DeliverAt: &deliverAt,
+		})
+		if err != nil {
+			t.Fatalf("schedule %s: %v", id, err)
+		}
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	now := &clock{now: time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)}
+	store, broker := newMemoryStore(), &memoryBroker{}
+	s := newTestScheduler("replica-1", store, &memoryLock{}, broker, now)
+	ctx := context.Background()
+
+	deliverAt := now.Now().Add(15 * time.Minute)
+	body := []byte(`{"form_id":"form-1"}`)
+	event, err := s.Schedule(ctx, &publishing.EventRequest{
+		EventType: "form.reminder.due",
+		Source:    "form-service",
+		RawData:   body,
+		Headers:   map[string]string{"tenant": "acme"},
+		DeliverAt: &deliverAt,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.ID == "" || event.Topic != publishing.TopicOf(&publishing.EventRequest{EventType: "form.reminder.due"}) ||
+		event.Status != StatusPending || !event.NextAttemptAt.Equal(deliverAt) {
+		t.Errorf("scheduled %+v", event)
+	}
+	// The data outlives the reused request body
+	copy(body, "xxxxxxxxxxxxxxxxxxxx")
+	if string(event.Data) != `{"form_id":"form-1"}` {
+		t.Errorf("data = %s, aliases the request body", event.Data)
+	}
+
+	tooLate := now.Now().Add(25 * time.Hour)
+	for _, req := range []*publishing.EventRequest{
+		{ID: "evt-late", EventType: "form.reminder.due", Source: "form-service", Data: map[string]interface{}{}, DeliverAt: &tooLate},
+		{ID: "evt-now", EventType: "form.reminder.due", Source: "form-service", Data: map[string]interface{}{}},
+	} {
+		if _, err := s.Schedule(ctx, req); !errors.Is(err, publishing.ErrInvalidEvent) {
+			t.Errorf("schedule %s: err = %v, want ErrInvalidEvent", req.ID, err)
+		}
+	}
+	schedule(t, s, time.Minute, "evt-1")
+	if _, err := s.Schedule(ctx, &publishing.EventRequest{ID: "evt-1", EventType: "form.reminder.due", Source: "form-service", Data: map[string]interface{}{}, DeliverAt: &deliverAt}); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("rescheduling evt-1: err = %v, want ErrDuplicate", err)
+	}
+
+	// Nothing is delivered before it is due
+	if _, err := s.Poll(ctx); err != nil || len(broker.published) != 0 {
+		t.Fatalf("early poll published %d events (%v)", len(broker.published), err)
+	}
+	now.Advance(15 * time.Minute)
+	if claimed, err := s.Poll(ctx); err != nil || claimed != 2 {
+		t.Fatalf("poll claimed %d events (%v), want 2", claimed, err)
+	}
+	delivered, _ := store.Get(ctx, event.ID)
+	if delivered.Status != StatusDelivered || delivered.Offset == nil || delivered.DeliveredAt == nil {
+		t.Errorf("delivered event = %+v", delivered)
+	}
+	for _, message := range broker.published {
+		if message.ID == event.ID && (message.Headers["tenant"] != "acme" || message.EventType != "form.reminder.due") {
+			t.Errorf("published %+v", message)
+		}
+	}
+	if got := testutil.ToFloat64(s.metrics.Events.WithLabelValues("delivered")); got != 2 {
+		t.Errorf("delivered metric = %v, want 2", got)
+	}
+	if got := testutil.CollectAndCount(s.metrics.DeliveryDelay); got != 1 {
+		t.Errorf("delivery delay collected %d series", got)
+	}
+}
+
+func TestFailedDeliveryIsRetried(t *testing.T) {
+	now := &clock{now: time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)}
+	store, broker := newMemoryStore(), &memoryBroker{}
+	down := true
+	producer := producerFunc(func(ctx context.Context, message *kafka.Message) (int32, int64, error) {
+		if down {
+			return 0, 0, errors.New("brokers unreachable")
+		}
+		return broker.SendMessage(ctx, message)
+	})
+	s := newTestScheduler("replica-1", store, &memoryLock{}, producer, now)
+	ctx := context.Background()
+	schedule(t, s, 0, "evt-1")
+
+	if _, err := s.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	event, _ := store.Get(ctx, "evt-1")
+	if event.Status != StatusPending || event.Attempts != 1 || event.LastError == "" || event.ClaimedUntil != nil {
+		t.Fatalf("event after a failed delivery = %+v", event)
+	}
+
+	// Not retried before the backoff, then delivered
+	down = false
+	s.Poll(ctx)
+	if len(broker.published) != 0 {
+		t.Fatal("retried before the backoff")
+	}
+	now.Advance(testConfig.RetryBackoff)
+	s.Poll(ctx)
+	if event, _ := store.Get(ctx, "evt-1"); event.Status != StatusDelivered || event.Attempts != 2 {
+		t.Errorf("event after the retry = %+v", event)
+	}
+}
+
+// TestCancelRacesDelivery cancels events around their claim by the leader.
+// Each event ends up either cancelled or delivered, never both.
+func TestCancelRacesDelivery(t *testing.T) {
+	now := &clock{now: time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)}
+	store, broker := newMemoryStore(), &memoryBroker{}
+	ctx := context.Background()
+
+	// The leader publishes evt-claimed while it is cancelled
+	var s *Scheduler
+	cancelled := make(map[string]error)
+	producer := producerFunc(func(ctx context.Context, message *kafka.Message) (int32, int64, error) {
+		if message.ID == "evt-claimed" {
+			_, cancelled[message.ID] = s.Cancel(ctx, message.ID)
+		}
+		return broker.SendMessage(ctx, message)
+	})
+	s = newTestScheduler("replica-1", store, &memoryLock{}, producer, now)
+	schedule(t, s, time.Minute, "evt-early", "evt-claimed", "evt-late")
+
+	// Cancelled before it is due, it is never claimed
+	if event, err := s.Cancel(ctx, "evt-early"); err != nil || event.Status != StatusCancelled {
+		t.Fatalf("cancel before due = %+v, %v", event, err)
+	}
+	now.Advance(time.Minute)
+	if _, err := s.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(cancelled["evt-claimed"], ErrDelivering) {
+		t.Errorf("cancel while publishing: err = %v, want ErrDelivering", cancelled["evt-claimed"])
+	}
+
+	// Once delivered, it can't be cancelled anymore
+	for id, want := range map[string]error{"evt-early": ErrNotPending, "evt-claimed": ErrNotPending, "evt-late": ErrNotPending, "evt-unknown": ErrNotFound} {
+		if _, err := s.Cancel(ctx, id); !errors.Is(err, want) {
+			t.Errorf("cancel %s: err = %v, want %v", id, err, want)
+		}
+	}
+	if got := broker.deliveries(); got["evt-early"] != 0 || got["evt-claimed"] != 1 || got["evt-late"] != 1 {
+		t.Errorf("deliveries = %v", got)
+	}
+
+	// Concurrent cancellations and polls: whatever the interleaving, an
+	// event is delivered exactly when its cancellation failed
+	ids := make([]string, 200)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("evt-race-%d", i)
+	}
+	schedule(t, s, 0, ids...)
+	results := make([]error, len(ids))
+	var wg sync.WaitGroup
+	for i := range ids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, results[i] = s.Cancel(ctx, ids[i])
+		}(i)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for j := 0; j < 5; j++ {
+			s.Poll(ctx)
+		}
+	}()
+	wg.Wait()
+	for {
+		if claimed, _ := s.Poll(ctx); claimed == 0 {
+			break
+		}
+	}
+
+	deliveries := broker.deliveries()
+	for i, id := range ids {
+		event, _ := store.Get(ctx, id)
+		switch {
+		case results[i] == nil && (event.Status != StatusCancelled || deliveries[id] != 0):
+			t.Errorf("%s was cancelled but is %s and delivered %d times", id, event.Status, deliveries[id])
+		case results[i] != nil && (event.Status != StatusDelivered || deliveries[id] != 1):
+			t.Errorf("%s failed to cancel (%v) but is %s and delivered %d times", id, results[i], event.Status, deliveries[id])
+		case results[i] != nil && !errors.Is(results[i], ErrDelivering) && !errors.Is(results[i], ErrNotPending):
+			t.Errorf("cancel %s: err = %v", id, results[i])
+		}
+	}
+}
+
+// TestLeaderFailoverMidPoll kills the leader while it publishes its claimed
+// events. The next leader delivers the events the first did not record,
+// after their claims lapse, without delivering the recorded ones again.
+func TestLeaderFailoverMidPoll(t *testing.T) {
+	now := &clock{now: time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)}
+	store, broker := newMemoryStore(), &memoryBroker{}
+	lock := &memoryLock{}
+	ctx := context.Background()
+
+	// The first leader dies right after publishing its third event, before
+	// recording it
+	published := 0
+	dying := producerFunc(func(ctx context.Context, message *kafka.Message) (int32, int64, error) {
+		partition, offset, err := broker.SendMessage(ctx, message)
+		if published++; published == 3 {
+			panic("replica-1 died")
+		}
+		return partition, offset, err
+	})
+	first := newTestScheduler("replica-1", store, lock, dying, now)
+	second := newTestScheduler("replica-2", store, lock, broker, now)
+	if _, err := first.Poll(ctx); err != nil || !first.Leading() {
+		t.Fatalf("first replica was not elected (%v)", err)
+	}
+	ids := []string{"evt-1", "evt-2", "evt-3", "evt-4", "evt-5"}
+	schedule(t, first, 0, ids...)
+
+	// The standby does not deliver while the first replica leads
+	if _, err := second.Poll(ctx); err != nil || second.Leading() {
+		t.Fatalf("standby leads (%v)", err)
+	}
+	func() {
+		defer func() { recover() }()
+		first.Poll(ctx)
+	}()
+	if len(broker.published) != 3 {
+		t.Fatalf("first leader published %d events before dying, want 3", len(broker.published))
+	}
+	lock.drop()
+
+	// The standby takes over but the claims of the dead leader hold,
+	if claimed, err := second.Poll(ctx); err != nil || !second.Leading() || claimed != 0 {
+		t.Fatalf("new leader claimed %d events before the claims lapsed (%v, leading %v)", claimed, err, second.Leading())
+	}
+	// Nor can the events under them be cancelled
+	for _, id := range ids {
+		if event, _ := store.Get(ctx, id); event.Status == StatusPending {
+			if _, err := second.Cancel(ctx, id); !errors.Is(err, ErrDelivering) {
+				t.Errorf("cancel of %s under a dead leader's claim: err = %v, want ErrDelivering", id, err)
+			}
+		}
+	}
+
+	now.Advance(testConfig.ClaimTimeout)
+	if claimed, err := second.Poll(ctx); err != nil || claimed != 3 {
+		t.Fatalf("new leader claimed %d events (%v), want the 3 unrecorded", claimed, err)
+	}
+
+	deliveries := broker.deliveries()
+	for _, id := range ids {
+		event, _ := store.Get(ctx, id)
+		if event.Status != StatusDelivered {
+			t.Errorf("%s is %s after the failover", id, event.Status)
+		}
+		if deliveries[id] == 0 {
+			t.Errorf("%s was never delivered", id)
+		}
+	}
+	// Delivery is at least once: the event published but not recorded by
+	// the dead leader is published again, with the same ID
+	redelivered := 0
+	for _, count := range deliveries {
+		if count > 1 {
+			redelivered += count - 1
+		}
+	}
+	if redelivered != 1 || len(broker.published) != 6 {
+		t.Errorf("%d events published, %d redelivered; want 6 with 1 redelivered", len(broker.published), redelivered)
+	}
+
+	// Resigning hands the leadership back
+	if err := second.elector.Resign(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if leading, _ := first.elector.Lead(ctx); !leading {
+		t.Error("the leadership was not handed over on resign")
+	}
+}
+
+func TestFilterNormalize(t *testing.T) {
+	from := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	to := from.Add(-time.Hour)
+	for _, filter := range []Filter{{Limit: 1001}, {Limit: -1}, {From: &from, To: &to}, {After: "not-a-cursor"}} {
+		if _, err := filter.normalize(); !errors.Is(err, ErrInvalidFilter) {
+			t.Errorf("normalize(%+v) err = %v, want ErrInvalidFilter", filter, err)
+		}
+	}
+
+	event := &Event{ID: "evt|1", DeliverAt: from.Add(123 * time.Microsecond)}
+	filter, err := Filter{After: cursor(event)}.normalize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filter.Limit != 100 || filter.afterID != "evt|1" || !filter.afterTime.Equal(event.DeliverAt) {
+		t.Errorf("normalized %+v", filter)
+	}
+}
+
+// TestPostgresStore schedules, lists, claims, cancels and delivers events
+// against a real database when EVENTBUS_TEST_DATABASE_URL is set
+func TestPostgresStore(t *testing.T) {
+	dsn := os.Getenv("EVENTBUS_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("EVENTBUS_TEST_DATABASE_URL not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if _, err := db.ExecContext(ctx, "DROP TABLE IF EXISTS scheduled_events"); err != nil {
+		t.Fatalf("failed to reset table: %v", err)
+	}
+	store := NewPostgresStore(db)
+	if err := store.EnsureSchema(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	for i := 1; i <= 3; i++ {
+		event := &Event{
+			ID: fmt.Sprintf("evt-%d", i), EventType: "form.reminder.due", Source: "form-service", Topic: "app.form.reminder.due",
+			Headers: map[string]string{"tenant": "acme"}, Data: json.RawMessage(`{"form_id": "form-1"}`),
+			DeliverAt: now.Add(time.Duration(i) * time.Minute), CreatedAt: now,
+		}
+		if err := store.Schedule(ctx, event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Schedule(ctx, &Event{ID: "evt-1", DeliverAt: now}); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("duplicate err = %v, want ErrDuplicate", err)
+	}
+
+	filter, _ := Filter{Topic: "app.form.reminder.due", Limit: 2}.normalize()
+	page, err := store.List(ctx, filter)
+	if err != nil || len(page.Events) != 2 || page.Events[0].ID != "evt-1" || page.NextCursor == "" {
+		t.Fatalf("first page = %+v, %v", page, err)
+	}
+	if page.Events[0].Headers["tenant"] != "acme" || !page.Events[0].DeliverAt.Equal(now.Add(time.Minute)) {
+		t.Errorf("listed %+v", page.Events[0])
+	}
+	filter, _ = Filter{Limit: 2, After: page.NextCursor}.normalize()
+	if page, err = store.List(ctx, filter); err != nil || len(page.Events) != 1 || page.Events[0].ID != "evt-3" {
+		t.Fatalf("second page = %+v, %v", page, err)
+	}
+
+	// Two replicas claiming at once never claim the same event
+	at := now.Add(2 * time.Minute)
+	claims := make([][]Event, 2)
+	var wg sync.WaitGroup
+	for i, owner := range []string{"replica-1", "replica-2"} {
+		wg.Add(1)
+		go func(i int, owner string) {
+			defer wg.Done()
+			claims[i], _ = store.Claim(ctx, owner, at, time.Minute, 10)
+		}(i, owner)
+	}
+	wg.Wait()
+	owners := make(map[string]string)
+	for i, owner := range []string{"replica-1", "replica-2"} {
+		for _, event := range claims[i] {
+			owners[event.ID] = owner
+		}
+	}
+	if len(owners) != 2 || len(claims[0])+len(claims[1]) != 2 {
+		t.Fatalf("claimed %d and %d events, want evt-1 and evt-2 once each", len(claims[0]), len(claims[1]))
+	}
+	if _, err := store.Cancel(ctx, "evt-1", at); !errors.Is(err, ErrDelivering) {
+		t.Errorf("cancel of a claimed event: err = %v, want ErrDelivering", err)
+	}
+	if err := store.MarkDelivered(ctx, "evt-1", "replica-3", 0, 42, at); !errors.Is(err, ErrClaimLost) {
+		t.Errorf("delivery recorded by another replica: err = %v, want ErrClaimLost", err)
+	}
+	if err := store.MarkDelivered(ctx, "evt-1", owners["evt-1"], 3, 42, at); err != nil {
+		t.Fatal(err)
+	}
+
+	delivered, err := store.Get(ctx, "evt-1")
+	if err != nil || delivered.Status != StatusDelivered || *delivered.Partition != 3 || *delivered.Offset != 42 {
+		t.Fatalf("delivered event = %+v, %v", delivered, err)
+	}
+	// The claim on evt-2 lapses, after which it can be cancelled
+	if event, err := store.Cancel(ctx, "evt-2", at.Add(time.Minute)); err != nil || event.Status != StatusCancelled {
+		t.Errorf("cancel after the claim lapsed = %+v, %v", event, err)
+	}
+	if _, err := store.Cancel(ctx, "evt-1", at); !errors.Is(err, ErrNotPending) {
+		t.Errorf("cancel of a delivered event: err = %v, want ErrNotPending", err)
+	}
+	if backlog, err := store.Backlog(ctx, at.Add(time.Hour)); err != nil || backlog.Pending != 1 || backlog.Due != 1 {
+		t.Errorf("backlog = %+v, %v", backlog, err)
+	}
+}
@@ -0,0 +1,293 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Schema creates the scheduled_events table
+const Schema = `
+CREATE TABLE IF NOT EXISTS scheduled_events (
+    id VARCHAR(255) PRIMARY KEY,
+    event_type VARCHAR(255) NOT NULL,
+    source VARCHAR(255) NOT NULL,
+    subject VARCHAR(255) NOT NULL DEFAULT '',
+    topic VARCHAR(255) NOT NULL,
+    message_key VARCHAR(255) NOT NULL DEFAULT '',
+    headers JSONB,
+    data JSONB NOT NULL,
+    deliver_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    claimed_by VARCHAR(255),
+    claimed_until TIMESTAMP WITH TIME ZONE,
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    kafka_partition INTEGER,
+    kafka_offset BIGINT,
+    cancelled_at TIMESTAMP WITH TIME ZONE
+);
+CREATE INDEX IF NOT EXISTS idx_scheduled_events_due ON scheduled_events(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_scheduled_events_pending ON scheduled_events(deliver_at, id) WHERE status = 'pending';
+`
+
+// eventColumns are the columns read for each event, in the order scanEvent
+// reads them
+const eventColumns = `id, event_type, source, subject, topic, message_key, headers, data,
+    deliver_at, status, created_at, next_attempt_at, attempts, last_error, claimed_by,
+    claimed_until, delivered_at, kafka_partition, kafka_offset, cancelled_at`
+
+// PostgresStore keeps the scheduled events in the scheduled_events table
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore creates a store writing through db
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// EnsureSchema creates the scheduled_events table and its indexes if missing
+func (s *PostgresStore) EnsureSchema(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, Schema); err != nil {
+		return fmt.Errorf("failed to create scheduled_events table: %w", err)
+	}
+	return nil
+}
+
+// Schedule inserts a pending event
+func (s *PostgresStore) Schedule(ctx context.Context, event *Event) error {
+	var headers interface{}
+	if len(event.Headers) > 0 {
+		encoded, err := json.Marshal(event.Headers)
+		if err != nil {
+			return fmt.Errorf("failed to encode headers: %w", err)
+		}
+		headers = string(encoded)
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO scheduled_events (id, event_type, source, subject, topic, message_key, headers, data,
+		    deliver_at, status, created_at, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, 'pending', $10, $9)
+		ON CONFLICT (id) DO NOTHING`,
+		event.ID, event.EventType, event.Source, event.Subject, event.Topic, event.Key, headers,
+		string(event.Data), event.DeliverAt, event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to schedule event %s: %w", event.ID, err)
+	}
+	if inserted, err := result.RowsAffected(); err == nil && inserted == 0 {
+		return ErrDuplicate
+	}
+	return nil
+}
+
+// Get returns an event
+func (s *PostgresStore) Get(ctx context.Context, id string) (*Event, error) {
+	events, err := s.queryEvents(ctx, "SELECT "+eventColumns+" FROM scheduled_events WHERE id = $1", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scheduled event %s: %w", id, err)
+	}
+	if len(events) == 0 {
+		return nil, ErrNotFound
+	}
+	return &events[0], nil
+}
+
+// List returns a page of the pending events matching filter, by delivery
+// time
+func (s *PostgresStore) List(ctx context.Context, filter Filter) (*Page, error) {
+	conditions := []string{"status = 'pending'"}
+	var args []interface{}
+	where := func(condition string, values ...interface{}) {
+		placeholders := make([]interface{}, len(values))
+		for i, value := range values {
+			args = append(args, value)
+			placeholders[i] = len(args)
+		}
+		conditions = append(conditions, fmt.Sprintf(condition, placeholders...))
+	}
+	if filter.Topic != "" {
+		where("topic = $%d", filter.Topic)
+	}
+	if filter.EventType != "" {
+		where("event_type = $%d", filter.EventType)
+	}
+	if filter.Source != "" {
+		where("source = $%d", filter.Source)
+	}
+	if filter.From != nil {
+		where("deliver_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		where("deliver_at < $%d", *filter.To)
+	}
+	if filter.afterID != "" {
+		where("(deliver_at, id) > ($%d, $%d)", filter.afterTime, filter.afterID)
+	}
+	args = append(args, filter.Limit)
+	query := fmt.Sprintf("SELECT %s FROM scheduled_events WHERE %s ORDER BY deliver_at, id LIMIT $%d",
+		eventColumns, strings.Join(conditions, " AND "), len(args))
+
+	events, err := s.queryEvents(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled events: %w", err)
+	}
+	page := &Page{Events: events}
+	if len(events) == filter.Limit {
+		page.NextCursor = cursor(&events[len(events)-1])
+	}
+	return page, nil
+}
+
+// Cancel cancels a pending event not claimed at now. The update is
+// conditional, so it can't interleave with a claim of the event.
+func (s *PostgresStore) Cancel(ctx context.Context, id string, now time.Time) (*Event, error) {
+	events, err := s.queryEvents(ctx, `
+		UPDATE scheduled_events SET status = 'cancelled', cancelled_at = $2
+		WHERE id = $1 AND status = 'pending' AND (claimed_until IS NULL OR claimed_until <= $2)
+		RETURNING `+eventColumns, id, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel scheduled event %s: %w", id, err)
+	}
+	if len(events) == 1 {
+		return &events[0], nil
+	}
+
+	event, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if event.Status != StatusPending {
+		return nil, ErrNotPending
+	}
+	return nil, ErrDelivering
+}
+
+// Claim claims the due events, skipping those locked by concurrent claims
+func (s *PostgresStore) Claim(ctx context.Context, owner string, now time.Time, lease time.Duration, limit int) ([]Event, error) {
+	return s.queryEvents(ctx, `
+		UPDATE scheduled_events SET claimed_by = $1, claimed_until = $3
+		WHERE id IN (
+		    SELECT id FROM scheduled_events
+		    WHERE status = 'pending' AND next_attempt_at <= $2
+		      AND (claimed_until IS NULL OR claimed_until <= $2)
+		    ORDER BY next_attempt_at
+		    LIMIT $4
+		    FOR UPDATE SKIP LOCKED)
+		RETURNING `+eventColumns, owner, now, now.Add(lease), limit)
+}
+
+// MarkDelivered records the delivery of an event claimed by owner
+func (s *PostgresStore) MarkDelivered(ctx context.Context, id, owner string, partition int32, offset int64, at time.Time) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE scheduled_events
+		SET status = 'delivered', delivered_at = $3, kafka_partition = $4, kafka_offset = $5,
+		    attempts = attempts + 1, claimed_by = NULL, claimed_until = NULL
+		WHERE id = $1 AND status = 'pending' AND claimed_by = $2`,
+		id, owner, at, partition, offset)
+	return claimUpdated(result, err, id)
+}
+
+// Release gives up the claim of owner on an event, to be retried at retryAt
+func (s *PostgresStore) Release(ctx context.Context, id, owner string, retryAt time.Time, cause string) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE scheduled_events
+		SET next_attempt_at = $3, attempts = attempts + 1, last_error = $4,
+		    claimed_by = NULL, claimed_until = NULL
+		WHERE id = $1 AND status = 'pending' AND claimed_by = $2`,
+		id, owner, retryAt, cause)
+	return claimUpdated(result, err, id)
+}
+
+// claimUpdated checks that an update conditioned on a claim updated the event
+func claimUpdated(result sql.Result, err error, id string) error {
+	if err != nil {
+		return fmt.Errorf("failed to update scheduled event %s: %w", id, err)
+	}
+	if updated, err := result.RowsAffected(); err == nil && updated == 0 {
+		return ErrClaimLost
+	}
+	return nil
+}
+
+// Backlog counts the pending events, and those due at now
+func (s *PostgresStore) Backlog(ctx context.Context, now time.Time) (Backlog, error) {
+	var backlog Backlog
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE next_attempt_at <= $1)
+		FROM scheduled_events WHERE status = 'pending'`, now).Scan(&backlog.Pending, &backlog.Due)
+	if err != nil {
+		return backlog, fmt.Errorf("failed to count scheduled events: %w", err)
+	}
+	return backlog, nil
+}
+
+func (s *PostgresStore) queryEvents(ctx context.Context, query string, args ...interface{}) ([]Event, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []Event{}
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *event)
+	}
+	return events, rows.Err()
+}
+
+func scanEvent(rows *sql.Rows) (*Event, error) {
+	var (
+		event                                  Event
+		headers, data                          []byte
+		claimedBy                              sql.NullString
+		claimedUntil, deliveredAt, cancelledAt sql.NullTime
+		partition                              sql.NullInt32
+		offset                                 sql.NullInt64
+	)
+	err := rows.Scan(&event.ID, &event.EventType, &event.Source, &event.Subject, &event.Topic, &event.Key,
+		&headers, &data, &event.DeliverAt, &event.Status, &event.CreatedAt, &event.NextAttemptAt,
+		&event.Attempts, &event.LastError, &claimedBy, &claimedUntil, &deliveredAt, &partition, &offset, &cancelledAt)
+	if err != nil {
+		return nil, err
+	}
+	if len(headers) > 0 {
+		if err := json.Unmarshal(headers, &event.Headers); err != nil {
+			return nil, fmt.Errorf("invalid headers of scheduled event %s: %w", event.ID, err)
+		}
+	}
+	event.Data = data
+	event.DeliverAt = event.DeliverAt.UTC()
+	event.CreatedAt = event.CreatedAt.UTC()
+	event.NextAttemptAt = event.NextAttemptAt.UTC()
+	event.ClaimedBy = claimedBy.String
+	event.ClaimedUntil = nullTime(claimedUntil)
+	event.DeliveredAt = nullTime(deliveredAt)
+	event.CancelledAt = nullTime(cancelledAt)
+	if partition.Valid {
+		event.Partition = &partition.Int32
+	}
+	if offset.Valid {
+		event.Offset = &offset.Int64
+	}
+	return &event, nil
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	utc := t.Time.UTC()
+	return &utc
+}
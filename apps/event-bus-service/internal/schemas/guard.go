@@ -0,0 +1,276 @@
+package schemas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+)
+
+// Compatibility modes of the guard
+const (
+	// ModeAutoRegister registers compatible changes as a new version and
+	// registers the first version of unregistered event types
+	ModeAutoRegister = "auto_register"
+	// ModeStrict rejects any change to a registered schema and leaves
+	// unregistered event types unchecked
+	ModeStrict = "strict"
+)
+
+// ErrIncompatible is matched by the IncompatibleError of a rejected event
+var ErrIncompatible = errors.New("incompatible schema")
+
+// IncompatibleError rejects an event whose data doesn't match the schema
+// registered for its type
+type IncompatibleError struct {
+	EventType string
+	Version   int
+	Changes   []Change
+}
+
+func (e *IncompatibleError) Error() string {
+	return fmt.Sprintf("data of %s is incompatible with schema version %d: %s", e.EventType, e.Version, describe(e.Changes))
+}
+
+// Is matches ErrIncompatible
+func (e *IncompatibleError) Is(target error) bool {
+	return target == ErrIncompatible
+}
+
+// CheckResult is whether a schema can be published for an event type
+type CheckResult struct {
+	EventType string `json:"event_type" example:"form.submitted"`
+	// LatestVersion is 0 when no schema is registered for the event type
+	LatestVersion int  `json:"latest_version" example:"3"`
+	Compatible    bool `json:"compatible"`
+	// Changes from the latest version, compatible or not
+	Changes []Change `json:"changes"`
+	// Schema is the schema checked, inferred when a sample was given
+	Schema *Schema `json:"schema"`
+}
+
+// Guard checks the data of published events against the latest schema
+// registered for their type. The latest versions are cached for a TTL.
+// When the registry can't be reached events are let through, so an outage
+// of the registry doesn't stop publishing.
+type Guard struct {
+	registry Registry
+	mode     string
+	ttl      time.Duration
+	metrics  *Metrics
+	logger   *zap.Logger
+	now      func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cachedVersion
+
+	// registering serializes registrations, so concurrent events carrying
+	// the same new field register one version
+	registering sync.Mutex
+}
+
+// cachedVersion is the latest version of an event type, nil when none is
+// registered
+type cachedVersion struct {
+	version *Version
+	expires time.Time
+}
+
+// NewGuard creates a guard checking events against registry in the mode of cfg
+func NewGuard(registry Registry, cfg config.SchemaCompatibilityConfig, metrics *Metrics, logger *zap.Logger) *Guard {
+	return &Guard{
+		registry: registry,
+		mode:     cfg.Mode,
+		ttl:      cfg.CacheTTL,
+		metrics:  metrics,
+		logger:   logger,
+		now:      time.Now,
+		cache:    make(map[string]cachedVersion),
+	}
+}
+
+// Check checks the data of an event of eventType. It returns an
+// IncompatibleError when the event is rejected.
+func (g *Guard) Check(ctx context.Context, eventType string, data map[string]interface{}) error {
+	inferred := Infer(data)
+	latest, err := g.latest(ctx, eventType)
+	if err != nil {
+		return g.failOpen(eventType, err)
+	}
+	if latest == nil && g.mode == ModeStrict {
+		g.record("unregistered")
+		return nil
+	}
+	if latest != nil {
+		changed, err := g.admit(eventType, latest, inferred)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			g.record("matched")
+			return nil
+		}
+	}
+	return g.register(ctx, eventType, latest, inferred)
+}
+
+// admit reports whether inferred differs from latest, returning the
+// IncompatibleError rejecting it for incompatible changes, or in strict mode
+// for any change
+func (g *Guard) admit(eventType string, latest *Version, inferred *Schema) (bool, error) {
+	changes := Compare(latest.Schema, inferred)
+	rejected := changes
+	if g.mode != ModeStrict {
+		rejected = Incompatible(changes)
+	}
+	if len(rejected) > 0 {
+		g.record("rejected")
+		return true, &IncompatibleError{EventType: eventType, Version: latest.Version, Changes: rejected}
+	}
+	return len(changes) > 0, nil
+}
+
+// register registers the schema of inferred as the next version after
+// latest, unless a concurrent event registered it first
+func (g *Guard) register(ctx context.Context, eventType string, latest *Version, inferred *Schema) error {
+	g.registering.Lock()
+	defer g.registering.Unlock()
+
+	if current, ok := g.cached(eventType); ok && current != nil && (latest == nil || current.Version > latest.Version) {
+		changed, err := g.admit(eventType, current, inferred)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			g.record("matched")
+			return nil
+		}
+		latest = current
+	}
+
+	schema := inferred
+	if latest != nil {
+		schema = Evolve(latest.Schema, inferred)
+	}
+	version, err := g.registry.Register(ctx, eventType, schema)
+	if err != nil {
+		return g.failOpen(eventType, err)
+	}
+	g.store(eventType, version)
+	g.record("registered")
+	g.logger.Info("Registered event schema",
+		zap.String("event_type", eventType),
+		zap.Int("version", version.Version))
+	return nil
+}
+
+// CheckSchema reports whether events of schema can be published for
+// eventType, reading the latest version from the registry
+func (g *Guard) CheckSchema(ctx context.Context, eventType string, schema *Schema) (*CheckResult, error) {
+	result := &CheckResult{EventType: eventType, Compatible: true, Changes: []Change{}, Schema: schema}
+	latest, err := g.registry.Latest(ctx, eventType)
+	if errors.Is(err, ErrSubjectNotFound) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	g.store(eventType, latest)
+
+	result.LatestVersion = latest.Version
+	if changes := Compare(latest.Schema, schema); len(changes) > 0 {
+		result.Changes = changes
+		result.Compatible = len(Incompatible(changes)) == 0
+	}
+	return result, nil
+}
+
+// Versions returns the registered versions of eventType, oldest first, or
+// ErrSubjectNotFound
+func (g *Guard) Versions(ctx context.Context, eventType string) ([]Version, error) {
+	return g.registry.Versions(ctx, eventType)
+}
+
+// LatestVersion returns the number of the latest registered version of
+// eventType, from the cache while it is fresh; ok is false when none is
+// registered
+func (g *Guard) LatestVersion(ctx context.Context, eventType string) (version int, ok bool, err error) {
+	latest, err := g.latest(ctx, eventType)
+	if err != nil || latest == nil {
+		return 0, false, err
+	}
+	return latest.Version, true, nil
+}
+
+// latest returns the latest version of eventType, nil when none is
+// registered, from the cache while it is fresh
+func (g *Guard) latest(ctx context.Context, eventType string) (*Version, error) {
+	if version, ok := g.cached(eventType); ok {
+		return version, nil
+	}
+	version, err := g.registry.Latest(ctx, eventType)
+	if errors.Is(err, ErrSubjectNotFound) {
+		version, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	g.store(eventType, version)
+	return version, nil
+}
+
+func (g *Guard) cached(eventType string) (*Version, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	cached, ok := g.cache[eventType]
+	if !ok || !g.now().Before(cached.expires) {
+		return nil, false
+	}
+	return cached.version, true
+}
+
+func (g *Guard) store(eventType string, version *Version) {
+	g.mu.Lock()
+	g.cache[eventType] = cachedVersion{version: version, expires: g.now().Add(g.ttl)}
+	g.mu.Unlock()
+}
+
+// failOpen lets an event through when the registry fails
+func (g *Guard) failOpen(eventType string, err error) error {
+	g.record("error")
+	g.logger.Warn("Schema registry is unavailable, publishing unchecked",
+		zap.String("event_type", eventType),
+		zap.Error(err))
+	return nil
+}
+
+func (g *Guard) record(result string) {
+	if g.metrics != nil {
+		g.metrics.Checks.WithLabelValues(result).Inc()
+	}
+}
+
+// Metrics contains the Prometheus metrics of the schema guard
+type Metrics struct {
+	// Checks counts the checked events by result: matched, registered,
+	// rejected, unregistered or error
+	Checks *prometheus.CounterVec
+}
+
+// NewMetrics creates the schema guard metrics and registers them with reg
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		Checks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "eventbus_schema_checks_total",
+			Help: "Total number of published events checked against their registered schema, by result",
+		}, []string{"result"}),
+	}
+	reg.MustRegister(m.Checks)
+	return m
+}
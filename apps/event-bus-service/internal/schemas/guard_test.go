@@ -0,0 +1,238 @@
+package schemas
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+)
+
+// fakeRegistry serves the subjects API of the Confluent Schema Registry
+// from memory
+type fakeRegistry struct {
+	mu       sync.Mutex
+	subjects map[string][]string
+	calls    int
+}
+
+func newFakeRegistry(t *testing.T) (*fakeRegistry, *httptest.Server) {
+	f := &fakeRegistry{subjects: make(map[string][]string)}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	return f, server
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+
+	if user, password, _ := r.BasicAuth(); user != "bus" || password != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	subject, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/subjects/"), "/versions")
+	versions, ok := f.subjects[subject]
+
+	switch {
+	case r.Method == http.MethodPost && rest == "":
+		var body registered
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.SchemaType != "JSON" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		f.subjects[subject] = append(versions, body.Schema)
+		json.NewEncoder(w).Encode(map[string]int{"id": 100 + len(f.subjects[subject])})
+	case !ok:
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error_code":40401,"message":"Subject not found."}`))
+	case rest == "":
+		numbers := make([]int, len(versions))
+		for i := range versions {
+			numbers[i] = i + 1
+		}
+		json.NewEncoder(w).Encode(numbers)
+	default:
+		n := len(versions)
+		if v := strings.TrimPrefix(rest, "/"); v != "latest" {
+			n, _ = strconv.Atoi(v)
+		}
+		json.NewEncoder(w).Encode(registered{Version: n, ID: 100 + n, SchemaType: "JSON", Schema: versions[n-1]})
+	}
+}
+
+func (f *fakeRegistry) register(t *testing.T, subject, sample string) {
+	t.Helper()
+	raw, _ := json.Marshal(Infer(decode(t, sample)))
+	f.mu.Lock()
+	f.subjects[subject] = append(f.subjects[subject], string(raw))
+	f.mu.Unlock()
+}
+
+func (f *fakeRegistry) versions(subject string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.subjects[subject])
+}
+
+func newTestGuard(t *testing.T, url, mode string) (*Guard, *Metrics) {
+	registry := NewConfluentRegistry(config.SchemaRegistryConfig{
+		URLs: []string{"http://127.0.0.1:1", url},
+		Auth: struct {
+			Username string `mapstructure:"username" yaml:"username" json:"username"`
+			Password string `mapstructure:"password" yaml:"password" json:"password"`
+		}{Username: "bus", Password: "secret"},
+	}, time.Second)
+	metrics := NewMetrics(prometheus.NewRegistry())
+	guard := NewGuard(registry, config.SchemaCompatibilityConfig{Enabled: true, Mode: mode, CacheTTL: time.Minute}, metrics, zap.NewNop())
+	return guard, metrics
+}
+
+func TestGuardAutoRegister(t *testing.T) {
+	fake, server := newFakeRegistry(t)
+	guard, metrics := newTestGuard(t, server.URL, ModeAutoRegister)
+	ctx := context.Background()
+
+	// The first event of a type registers its schema
+	if err := guard.Check(ctx, "form.submitted", decode(t, `{"form_id":"f-1","answers":{}}`)); err != nil {
+		t.Fatalf("first event: %v", err)
+	}
+	if fake.versions("form.submitted") != 1 {
+		t.Fatalf("versions = %d after the first event, want 1", fake.versions("form.submitted"))
+	}
+
+	// A compatible addition registers the next version, once; the answers
+	// registered without fields accept any
+	for i := 0; i < 3; i++ {
+		if err := guard.Check(ctx, "form.submitted", decode(t, `{"form_id":"f-1","answers":{"q-1":"yes"},"channel":"web"}`)); err != nil {
+			t.Fatalf("event with an added field: %v", err)
+		}
+	}
+	if fake.versions("form.submitted") != 2 {
+		t.Fatalf("versions = %d after an added field, want 2", fake.versions("form.submitted"))
+	}
+	// Events without the added field are still accepted
+	if err := guard.Check(ctx, "form.submitted", decode(t, `{"form_id":"f-2","answers":{}}`)); err != nil {
+		t.Errorf("event without the added field: %v", err)
+	}
+
+	err := guard.Check(ctx, "form.submitted", decode(t, `{"form_id":12,"channel":"web"}`))
+	var incompatible *IncompatibleError
+	if !errors.As(err, &incompatible) || !errors.Is(err, ErrIncompatible) {
+		t.Fatalf("incompatible event: err = %v", err)
+	}
+	if incompatible.Version != 2 || len(incompatible.Changes) != 2 ||
+		incompatible.Changes[0].Kind != KindRemovedRequiredField || incompatible.Changes[1].Kind != KindTypeChanged {
+		t.Errorf("rejection = %+v", incompatible)
+	}
+	if fake.versions("form.submitted") != 2 {
+		t.Error("an incompatible schema was registered")
+	}
+
+	for result, want := range map[string]float64{"registered": 2, "matched": 3, "rejected": 1} {
+		if got := testutil.ToFloat64(metrics.Checks.WithLabelValues(result)); got != want {
+			t.Errorf("%s checks = %v, want %v", result, got, want)
+		}
+	}
+}
+
+func TestGuardStrict(t *testing.T) {
+	fake, server := newFakeRegistry(t)
+	fake.register(t, "form.created", `{"form_id":"f-1","title":"Survey"}`)
+	guard, _ := newTestGuard(t, server.URL, ModeStrict)
+	ctx := context.Background()
+
+	if err := guard.Check(ctx, "form.created", decode(t, `{"form_id":"f-2","title":"Poll"}`)); err != nil {
+		t.Errorf("matching event: %v", err)
+	}
+	if err := guard.Check(ctx, "form.created", decode(t, `{"form_id":"f-2","title":"Poll","owner":"u-1"}`)); !errors.Is(err, ErrIncompatible) {
+		t.Errorf("event with an added field: err = %v, want ErrIncompatible", err)
+	}
+	// Unregistered types are not checked, nor registered
+	if err := guard.Check(ctx, "form.deleted", decode(t, `{"form_id":"f-2"}`)); err != nil {
+		t.Errorf("unregistered event type: %v", err)
+	}
+	if fake.versions("form.created") != 1 || fake.versions("form.deleted") != 0 {
+		t.Error("strict mode registered a schema")
+	}
+}
+
+func TestGuardCachesLatestVersion(t *testing.T) {
+	fake, server := newFakeRegistry(t)
+	fake.register(t, "form.created", `{"form_id":"f-1"}`)
+	guard, _ := newTestGuard(t, server.URL, ModeStrict)
+	now := time.Now()
+	guard.now = func() time.Time { return now }
+
+	for i := 0; i < 5; i++ {
+		if err := guard.Check(context.Background(), "form.created", decode(t, `{"form_id":"f-1"}`)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if fake.calls != 1 {
+		t.Errorf("registry calls = %d, want 1", fake.calls)
+	}
+	now = now.Add(2 * time.Minute)
+	guard.Check(context.Background(), "form.created", decode(t, `{"form_id":"f-1"}`))
+	if fake.calls != 2 {
+		t.Errorf("registry calls = %d after the TTL, want 2", fake.calls)
+	}
+}
+
+func TestGuardFailsOpen(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	guard, metrics := newTestGuard(t, server.URL, ModeAutoRegister)
+
+	if err := guard.Check(context.Background(), "form.created", map[string]interface{}{"form_id": "f-1"}); err != nil {
+		t.Errorf("event checked while the registry fails: %v", err)
+	}
+	if got := testutil.ToFloat64(metrics.Checks.WithLabelValues("error")); got != 1 {
+		t.Errorf("error checks = %v, want 1", got)
+	}
+}
+
+func TestCheckSchemaAndVersions(t *testing.T) {
+	fake, server := newFakeRegistry(t)
+	fake.register(t, "form.submitted", `{"form_id":"f-1"}`)
+	fake.register(t, "form.submitted", `{"form_id":"f-1","score":3}`)
+	guard, _ := newTestGuard(t, server.URL, ModeAutoRegister)
+	ctx := context.Background()
+
+	versions, err := guard.Versions(ctx, "form.submitted")
+	if err != nil || len(versions) != 2 || versions[1].Version != 2 || versions[1].Schema.Properties["score"].Type != TypeInteger {
+		t.Fatalf("Versions() = %+v, %v", versions, err)
+	}
+	if _, err := guard.Versions(ctx, "form.deleted"); !errors.Is(err, ErrSubjectNotFound) {
+		t.Errorf("versions of an unregistered type: err = %v, want ErrSubjectNotFound", err)
+	}
+
+	result, err := guard.CheckSchema(ctx, "form.submitted", Infer(decode(t, `{"form_id":"f-1","score":"high"}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Compatible || result.LatestVersion != 2 || len(result.Changes) != 1 || result.Changes[0].Path != "score" {
+		t.Errorf("CheckSchema() = %+v", result)
+	}
+	result, err = guard.CheckSchema(ctx, "form.deleted", Infer(decode(t, `{"form_id":"f-1"}`)))
+	if err != nil || !result.Compatible || result.LatestVersion != 0 {
+		t.Errorf("CheckSchema() of an unregistered type = %+v, %v", result, err)
+	}
+	if fake.versions("form.submitted") != 2 || fake.versions("form.deleted") != 0 {
+		t.Error("checking a schema registered it")
+	}
+}
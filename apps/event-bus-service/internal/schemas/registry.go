@@ -0,0 +1,172 @@
+package schemas
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+)
+
+// ErrSubjectNotFound is returned for event types without a registered schema
+var ErrSubjectNotFound = errors.New("no schema registered")
+
+// Version is a registered version of the schema of an event type
+type Version struct {
+	Version int     `json:"version" example:"3"`
+	ID      int     `json:"id" example:"42"`
+	Schema  *Schema `json:"schema"`
+}
+
+// Registry stores the schema versions of the event types, one subject per
+// event type
+type Registry interface {
+	// Latest returns the latest version of subject, or ErrSubjectNotFound
+	Latest(ctx context.Context, subject string) (*Version, error)
+	// Versions returns every version of subject, oldest first, or
+	// ErrSubjectNotFound
+	Versions(ctx context.Context, subject string) ([]Version, error)
+	// Register registers schema as the next version of subject
+	Register(ctx context.Context, subject string, schema *Schema) (*Version, error)
+}
+
+// contentType is the media type of the Confluent Schema Registry API
+const contentType = "application/vnd.schemaregistry.v1+json"
+
+// ConfluentRegistry is a Registry backed by the Confluent Schema Registry,
+// storing JSON schemas. Each URL is tried in turn until one answers.
+type ConfluentRegistry struct {
+	urls     []string
+	username string
+	password string
+	client   *http.Client
+}
+
+// NewConfluentRegistry creates a client of the registry of cfg
+func NewConfluentRegistry(cfg config.SchemaRegistryConfig, timeout time.Duration) *ConfluentRegistry {
+	urls := make([]string, len(cfg.URLs))
+	for i, u := range cfg.URLs {
+		urls[i] = strings.TrimSuffix(u, "/")
+	}
+	return &ConfluentRegistry{
+		urls:     urls,
+		username: cfg.Auth.Username,
+		password: cfg.Auth.Password,
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+// registered is a schema version as the registry answers it
+type registered struct {
+	Version    int    `json:"version"`
+	ID         int    `json:"id"`
+	SchemaType string `json:"schemaType"`
+	Schema     string `json:"schema"`
+}
+
+// Latest returns the latest version of subject
+func (r *ConfluentRegistry) Latest(ctx context.Context, subject string) (*Version, error) {
+	return r.version(ctx, subject, "latest")
+}
+
+// Versions returns every version of subject, oldest first
+func (r *ConfluentRegistry) Versions(ctx context.Context, subject string) ([]Version, error) {
+	var numbers []int
+	if err := r.do(ctx, http.MethodGet, subjectPath(subject)+"/versions", nil, &numbers); err != nil {
+		return nil, err
+	}
+	versions := make([]Version, 0, len(numbers))
+	for _, n := range numbers {
+		v, err := r.version(ctx, subject, fmt.Sprint(n))
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, *v)
+	}
+	return versions, nil
+}
+
+// Register registers schema as the next version of subject. The registry
+// answers the ID of the schema only, so the version is read back.
+func (r *ConfluentRegistry) Register(ctx context.Context, subject string, schema *Schema) (*Version, error) {
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(registered{SchemaType: "JSON", Schema: string(raw)})
+	if err != nil {
+		return nil, err
+	}
+	if err := r.do(ctx, http.MethodPost, subjectPath(subject)+"/versions", body, nil); err != nil {
+		return nil, err
+	}
+	return r.Latest(ctx, subject)
+}
+
+func (r *ConfluentRegistry) version(ctx context.Context, subject, version string) (*Version, error) {
+	var v registered
+	if err := r.do(ctx, http.MethodGet, subjectPath(subject)+"/versions/"+version, nil, &v); err != nil {
+		return nil, err
+	}
+	var schema Schema
+	if err := json.Unmarshal([]byte(v.Schema), &schema); err != nil {
+		return nil, fmt.Errorf("schema %d of %s is not JSON: %w", v.Version, subject, err)
+	}
+	return &Version{Version: v.Version, ID: v.ID, Schema: &schema}, nil
+}
+
+func subjectPath(subject string) string {
+	return "/subjects/" + url.PathEscape(subject)
+}
+
+// do calls the registry, decoding the answer into out when it isn't nil
+func (r *ConfluentRegistry) do(ctx context.Context, method, path string, body []byte, out interface{}) error {
+	var lastErr error
+	for _, base := range r.urls {
+		req, err := http.NewRequestWithContext(ctx, method, base+path, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Accept", contentType)
+		if body != nil {
+			req.Header.Set("Content-Type", contentType)
+		}
+		if r.username != "" {
+			req.SetBasicAuth(r.username, r.password)
+		}
+
+		resp, err := r.client.Do(req)
+		if err != nil {
+			// The next registry may answer
+			lastErr = fmt.Errorf("failed to call the schema registry: %w", err)
+			continue
+		}
+		defer resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusNotFound:
+			return fmt.Errorf("%w at %s", ErrSubjectNotFound, path)
+		case resp.StatusCode >= 300:
+			message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			return fmt.Errorf("schema registry answered %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+		}
+		if out == nil {
+			return nil
+		}
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("invalid schema registry answer: %w", err)
+		}
+		return nil
+	}
+	if lastErr == nil {
+		lastErr = errors.New("no schema registry URL is configured")
+	}
+	return lastErr
+}
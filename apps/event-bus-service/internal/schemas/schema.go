@@ -0,0 +1,271 @@
+// Package schemas keeps the payloads of published events compatible with the
+// consumers downstream. The data of each event type is checked against the
+// latest JSON schema registered for the type in the schema registry; payloads
+// that would break the consumers of the type are rejected, and compatible
+// additions can be registered as a new version.
+package schemas
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// JSON schema types
+const (
+	TypeObject  = "object"
+	TypeArray   = "array"
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeInteger = "integer"
+	TypeBoolean = "boolean"
+)
+
+// Schema is the subset of JSON schema the compatibility rules look at. Other
+// keywords of a registered schema are ignored. An object without properties
+// accepts any fields, which suits maps keyed by IDs such as the answers of a
+// response.
+type Schema struct {
+	// Type is empty when any value is accepted, as for a field seen null
+	Type       string             `json:"type,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+}
+
+// Infer returns the schema of a sample payload. Every field present and not
+// null is required; numbers without a fraction are integers; the items of an
+// array take the schema of its first element.
+func Infer(sample interface{}) *Schema {
+	switch v := sample.(type) {
+	case map[string]interface{}:
+		s := &Schema{Type: TypeObject}
+		for name, value := range v {
+			if s.Properties == nil {
+				s.Properties = make(map[string]*Schema, len(v))
+			}
+			s.Properties[name] = Infer(value)
+			if value != nil {
+				s.Required = append(s.Required, name)
+			}
+		}
+		sort.Strings(s.Required)
+		return s
+	case []interface{}:
+		s := &Schema{Type: TypeArray}
+		if len(v) > 0 {
+			s.Items = Infer(v[0])
+		}
+		return s
+	case string:
+		return &Schema{Type: TypeString}
+	case bool:
+		return &Schema{Type: TypeBoolean}
+	case float64:
+		if v == math.Trunc(v) {
+			return &Schema{Type: TypeInteger}
+		}
+		return &Schema{Type: TypeNumber}
+	case float32, int, int32, int64, uint, uint32, uint64:
+		return &Schema{Type: TypeInteger}
+	default:
+		return &Schema{}
+	}
+}
+
+// Kinds of change between two schemas
+const (
+	// KindAddedField is a field the registered schema doesn't have
+	KindAddedField = "added_field"
+	// KindRemovedRequiredField is a required field missing from the new schema
+	KindRemovedRequiredField = "removed_required_field"
+	// KindRequiredFieldMadeOptional is a required field the new schema
+	// doesn't require
+	KindRequiredFieldMadeOptional = "required_field_made_optional"
+	// KindTypeChanged is a field whose values have changed type
+	KindTypeChanged = "type_changed"
+)
+
+// Change is a difference between a registered schema and a new one
+type Change struct {
+	// Path of the field, dotted, with [] for the items of an array
+	Path string `json:"path" example:"respondent.email"`
+	Kind string `json:"kind" example:"removed_required_field"`
+	// Compatible is true when the consumers of the registered schema can
+	// read data of the new one
+	Compatible bool   `json:"compatible"`
+	Old        string `json:"old,omitempty" example:"string"`
+	New        string `json:"new,omitempty"`
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case KindAddedField:
+		return fmt.Sprintf("field %s was added", c.Path)
+	case KindRemovedRequiredField:
+		return fmt.Sprintf("required field %s was removed", c.Path)
+	case KindRequiredFieldMadeOptional:
+		return fmt.Sprintf("required field %s is missing or null", c.Path)
+	case KindTypeChanged:
+		return fmt.Sprintf("field %s changed from %s to %s", c.Path, c.Old, c.New)
+	}
+	return c.Kind + " " + c.Path
+}
+
+// Compare lists the changes from old to new, sorted by path. A change is
+// compatible when the consumers of old can still read data of new: fields
+// may be added, but required fields must stay required and no field may
+// change type. An integer may be read where a number was, not the reverse.
+func Compare(old, new *Schema) []Change {
+	var changes []Change
+	compare("", old, new, &changes)
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// Incompatible returns the changes that are not compatible
+func Incompatible(changes []Change) []Change {
+	var incompatible []Change
+	for _, c := range changes {
+		if !c.Compatible {
+			incompatible = append(incompatible, c)
+		}
+	}
+	return incompatible
+}
+
+func compare(path string, old, new *Schema, changes *[]Change) {
+	if old == nil || new == nil {
+		return
+	}
+	if !assignable(old.Type, new.Type) {
+		*changes = append(*changes, Change{Path: rootPath(path), Kind: KindTypeChanged, Old: old.Type, New: new.Type})
+		return
+	}
+
+	if old.Type == TypeObject && old.Properties == nil {
+		return
+	}
+
+	required := make(map[string]bool, len(new.Required))
+	for _, name := range new.Required {
+		required[name] = true
+	}
+	for _, name := range old.Required {
+		if _, ok := new.Properties[name]; !ok {
+			*changes = append(*changes, Change{Path: join(path, name), Kind: KindRemovedRequiredField, Old: old.Properties[name].typeName()})
+		} else if !required[name] {
+			*changes = append(*changes, Change{Path: join(path, name), Kind: KindRequiredFieldMadeOptional, Old: old.Properties[name].typeName()})
+		}
+	}
+	for name, prop := range new.Properties {
+		oldProp, ok := old.Properties[name]
+		if !ok {
+			*changes = append(*changes, Change{Path: join(path, name), Kind: KindAddedField, Compatible: true, New: prop.typeName()})
+			continue
+		}
+		compare(join(path, name), oldProp, prop, changes)
+	}
+	compare(path+"[]", old.Items, new.Items, changes)
+}
+
+// assignable reports whether values of type new can be read as type old.
+// An empty type is any value, which includes null.
+func assignable(old, new string) bool {
+	return old == "" || new == "" || old == new || (old == TypeNumber && new == TypeInteger)
+}
+
+func (s *Schema) typeName() string {
+	if s == nil {
+		return ""
+	}
+	return s.Type
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func rootPath(path string) string {
+	if path == "" {
+		return "$"
+	}
+	return path
+}
+
+// Evolve returns latest with the fields of sample it doesn't have added as
+// optional fields, at any depth. It is the next version registered for
+// compatible changes; latest is left unchanged.
+func Evolve(latest, sample *Schema) *Schema {
+	next := latest.clone()
+	evolve(next, sample)
+	return next
+}
+
+func evolve(s, sample *Schema) {
+	// Objects without properties already accept any field
+	if s == nil || sample == nil || s.Type != sample.Type || s.Type == TypeObject && s.Properties == nil {
+		return
+	}
+	for name, prop := range sample.Properties {
+		if existing, ok := s.Properties[name]; ok {
+			evolve(existing, prop)
+			continue
+		}
+		s.Properties[name] = prop.optional()
+	}
+	if s.Items != nil {
+		evolve(s.Items, sample.Items)
+	} else if sample.Items != nil && s.Type == TypeArray {
+		s.Items = sample.Items.optional()
+	}
+}
+
+// optional returns a copy of s requiring none of its nested fields, so data
+// missing them stays valid
+func (s *Schema) optional() *Schema {
+	c := s.clone()
+	var strip func(*Schema)
+	strip = func(s *Schema) {
+		if s == nil {
+			return
+		}
+		s.Required = nil
+		for _, prop := range s.Properties {
+			strip(prop)
+		}
+		strip(s.Items)
+	}
+	strip(c)
+	return c
+}
+
+func (s *Schema) clone() *Schema {
+	if s == nil {
+		return nil
+	}
+	c := &Schema{Type: s.Type, Items: s.Items.clone()}
+	if s.Required != nil {
+		c.Required = append([]string(nil), s.Required...)
+	}
+	if s.Properties != nil {
+		c.Properties = make(map[string]*Schema, len(s.Properties))
+		for name, prop := range s.Properties {
+			c.Properties[name] = prop.clone()
+		}
+	}
+	return c
+}
+
+// describe joins the descriptions of changes
+func describe(changes []Change) string {
+	parts := make([]string, len(changes))
+	for i, c := range changes {
+		parts[i] = c.String()
+	}
+	return strings.Join(parts, "; ")
+}
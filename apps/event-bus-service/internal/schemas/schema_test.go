@@ -0,0 +1,108 @@
+package schemas
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func decode(t *testing.T, raw string) map[string]interface{} {
+	t.Helper()
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &data); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestInfer(t *testing.T) {
+	got := Infer(decode(t, `{"form_id":"f-1","score":4,"ratio":0.5,"tags":["a"],"respondent":{"email":"a@b.c","name":null},"draft":false}`))
+	want := &Schema{
+		Type:     TypeObject,
+		Required: []string{"draft", "form_id", "ratio", "respondent", "score", "tags"},
+		Properties: map[string]*Schema{
+			"form_id": {Type: TypeString},
+			"score":   {Type: TypeInteger},
+			"ratio":   {Type: TypeNumber},
+			"tags":    {Type: TypeArray, Items: &Schema{Type: TypeString}},
+			"draft":   {Type: TypeBoolean},
+			"respondent": {
+				Type:       TypeObject,
+				Required:   []string{"email"},
+				Properties: map[string]*Schema{"email": {Type: TypeString}, "name": {}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		gotJSON, _ := json.Marshal(got)
+		wantJSON, _ := json.Marshal(want)
+		t.Errorf("Infer() = %s\nwant %s", gotJSON, wantJSON)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	registered := Infer(map[string]interface{}{
+		"form_id":    "f-1",
+		"score":      1.5,
+		"count":      3.0,
+		"respondent": map[string]interface{}{"email": "a@b.c"},
+		"answers":    []interface{}{map[string]interface{}{"question_id": "q-1"}},
+	})
+	registered.Properties["note"] = &Schema{Type: TypeString}
+	registered.Properties["metadata"] = &Schema{Type: TypeObject}
+
+	tests := []struct {
+		name   string
+		sample string
+		want   []Change
+	}{
+		{"same fields", `{"form_id":"f-2","score":2.5,"count":1,"respondent":{"email":"x"},"answers":[],"metadata":{}}`, nil},
+		{"fields of an object without properties", `{"form_id":"f-2","score":2.5,"count":1,"respondent":{"email":"x"},"answers":[],"metadata":{"ip":"::1"}}`, nil},
+		{"integer where a number was", `{"form_id":"f-2","score":2,"count":1,"respondent":{"email":"x"},"answers":[]}`, nil},
+		{"added fields", `{"form_id":"f-2","score":2.5,"count":1,"respondent":{"email":"x","locale":"en"},"answers":[],"channel":"web"}`, []Change{
+			{Path: "channel", Kind: KindAddedField, Compatible: true, New: TypeString},
+			{Path: "respondent.locale", Kind: KindAddedField, Compatible: true, New: TypeString},
+		}},
+		{"removed required field", `{"score":2.5,"count":1,"respondent":{},"answers":[]}`, []Change{
+			{Path: "form_id", Kind: KindRemovedRequiredField, Old: TypeString},
+			{Path: "respondent.email", Kind: KindRemovedRequiredField, Old: TypeString},
+		}},
+		{"null required field", `{"form_id":null,"score":2.5,"count":1,"respondent":{"email":"x"},"answers":[]}`, []Change{
+			{Path: "form_id", Kind: KindRequiredFieldMadeOptional, Old: TypeString},
+		}},
+		{"type changes", `{"form_id":7,"score":2.5,"count":1.5,"respondent":"a@b.c","answers":[{"question_id":1}]}`, []Change{
+			{Path: "answers[].question_id", Kind: KindTypeChanged, Old: TypeString, New: TypeInteger},
+			{Path: "count", Kind: KindTypeChanged, Old: TypeInteger, New: TypeNumber},
+			{Path: "form_id", Kind: KindTypeChanged, Old: TypeString, New: TypeInteger},
+			{Path: "respondent", Kind: KindTypeChanged, Old: TypeObject, New: TypeString},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Compare(registered, Infer(decode(t, tt.sample)))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Compare() = %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvolve(t *testing.T) {
+	latest := Infer(decode(t, `{"form_id":"f-1","respondent":{"email":"a@b.c"}}`))
+	sample := Infer(decode(t, `{"form_id":"f-1","respondent":{"email":"a@b.c","locale":"en"},"meta":{"channel":"web"}}`))
+
+	next := Evolve(latest, sample)
+	if changes := Compare(latest, next); len(Incompatible(changes)) > 0 || len(changes) != 2 {
+		t.Errorf("changes from latest = %+v, want the 2 added fields", changes)
+	}
+	if changes := Compare(next, sample); len(changes) != 0 {
+		t.Errorf("sample differs from the evolved schema: %+v", changes)
+	}
+	// Events without the added fields stay valid
+	if changes := Compare(next, latest); len(Incompatible(changes)) > 0 {
+		t.Errorf("data of the latest schema is incompatible with the evolved one: %+v", changes)
+	}
+	if len(latest.Properties) != 2 || len(latest.Properties["respondent"].Properties) != 1 {
+		t.Error("latest was modified")
+	}
+}
@@ -0,0 +1,428 @@
+// Package stream serves GET /events/stream, which tails live events over
+// Server-Sent Events. Every stream reads through an ephemeral consumer group
+// of its own, starting at the latest offsets, so streams never affect the
+// offsets of the service's consumers and each sees every matching event.
+package stream
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+)
+
+// Subscriber attaches the ephemeral consumers of streams. It is implemented
+// by *kafka.Client.
+type Subscriber interface {
+	// ListTopics returns the topics stream patterns are matched against
+	ListTopics(ctx context.Context) ([]string, error)
+	// Subscribe joins groupID reading topics from their latest offsets
+	Subscribe(ctx context.Context, groupID string, topics []string) (kafka.Subscription, error)
+}
+
+// Handler serves event streams
+type Handler struct {
+	cfg        config.StreamConfig
+	apiKeys    config.APIKeysConfig
+	subscriber Subscriber
+	metrics    *Metrics
+	logger     *zap.Logger
+
+	// slots holds a token per open stream
+	slots    chan struct{}
+	stopping chan struct{}
+	stopOnce sync.Once
+}
+
+// NewHandler creates the stream handler of cfg, reading through subscriber
+func NewHandler(cfg *config.Config, subscriber Subscriber, metrics *Metrics, logger *zap.Logger) *Handler {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	if metrics == nil {
+		metrics = NewMetrics(prometheus.NewRegistry())
+	}
+
+	return &Handler{
+		cfg:        cfg.Server.Stream,
+		apiKeys:    cfg.Security.APIKeys,
+		subscriber: subscriber,
+		metrics:    metrics,
+		logger:     logger,
+		slots:      make(chan struct{}, cfg.Server.Stream.MaxStreams),
+		stopping:   make(chan struct{}),
+	}
+}
+
+// Close ends every open stream. The HTTP server does not cancel the requests
+// of streams when shutting down, so it calls Close instead.
+func (h *Handler) Close() {
+	h.stopOnce.Do(func() { close(h.stopping) })
+}
+
+// filter selects the events sent to a stream
+type filter struct {
+	eventTypes map[string]bool
+	sources    map[string]bool
+}
+
+func (f filter) matches(message *kafka.Message) bool {
+	if len(f.eventTypes) > 0 && !f.eventTypes[message.EventType] {
+		return false
+	}
+	if len(f.sources) > 0 && !f.sources[message.Source] {
+		return false
+	}
+	return true
+}
+
+// ServeHTTP streams the events of the topics matching the topics query
+// parameter, optionally narrowed with event_types and sources. Parameters are
+// comma-separated lists and may be repeated; topics are path.Match patterns.
+//
+// @Summary     Stream live events
+// @Description Server-Sent Events: each event is sent as an "id:" and a "data:" line holding the event JSON. Comments report heartbeats and events dropped by server.stream.rate_limit. Requires server.stream.enabled.
+// @Tags        events
+// @Produce     text/event-stream
+// @Security    ApiKeyAuth
+// @Param       topics      query    []string true  "Topic patterns" collectionFormat(csv)
+// @Param       event_types query    []string false "Event types to send" collectionFormat(csv)
+// @Param       sources     query    []string false "Sources to send" collectionFormat(csv)
+// @Success     200         {string} string   "Event stream"
+// @Failure     400         {object} main.ErrorResponse
+// @Failure     401         {object} main.ErrorResponse
+// @Failure     404         {object} main.ErrorResponse "No topic matches"
+// @Failure     405         {object} main.ErrorResponse
+// @Failure     429         {object} main.ErrorResponse "Too many open streams"
+// @Failure     503         {object} main.ErrorResponse
+// @Router      /events/stream [get]
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
+		return
+	}
+	if !h.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		respondError(w, http.StatusUnauthorized, "A valid API key is required", nil)
+		return
+	}
+
+	query := r.URL.Query()
+	patterns := listParam(query["topics"])
+	if len(patterns) == 0 {
+		respondError(w, http.StatusBadRequest, "At least one topic is required", nil)
+		return
+	}
+	topics, err := h.matchTopics(r.Context(), patterns)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid topic pattern", err)
+		return
+	}
+	if len(topics) == 0 {
+		respondError(w, http.StatusNotFound, "No topic matches", nil)
+		return
+	}
+	f := filter{eventTypes: setOf(listParam(query["event_types"])), sources: setOf(listParam(query["sources"]))}
+
+	select {
+	case h.slots <- struct{}{}:
+		defer func() { <-h.slots }()
+	default:
+		w.Header().Set("Retry-After", "10")
+		respondError(w, http.StatusTooManyRequests, "Too many open streams", nil)
+		return
+	}
+
+	groupID := h.cfg.GroupPrefix + "-" + randomID()
+	subscription, err := h.subscriber.Subscribe(r.Context(), groupID, topics)
+	if err != nil {
+		h.logger.Error("Failed to subscribe event stream", zap.Strings("topics", topics), zap.Error(err))
+		respondError(w, http.StatusServiceUnavailable, "Failed to subscribe to topics", err)
+		return
+	}
+	defer func() {
+		if err := subscription.Close(); err != nil {
+			h.logger.Warn("Failed to close event stream subscription", zap.String("group_id", groupID), zap.Error(err))
+		}
+	}()
+
+	h.metrics.Active.Inc()
+	defer h.metrics.Active.Dec()
+	h.logger.Info("Event stream opened",
+		zap.String("group_id", groupID),
+		zap.Strings("topics", topics),
+		zap.String("remote_addr", r.RemoteAddr))
+
+	h.stream(w, r, subscription, topics, f)
+
+	h.logger.Info("Event stream closed", zap.String("group_id", groupID))
+}
+
+// stream writes the events of subscription until the client disconnects,
+// the subscription ends or the handler is closed
+func (h *Handler) stream(w http.ResponseWriter, r *http.Request, subscription kafka.Subscription, topics []string, f filter) {
+	rc := http.NewResponseController(w)
+	// Streams outlive the server's write timeout
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		h.logger.Warn("Failed to clear the write deadline of an event stream", zap.Error(err))
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, ": streaming %s\n\n", strings.Join(topics, ", "))
+	if err := rc.Flush(); err != nil {
+		h.logger.Warn("Event stream can't be flushed", zap.Error(err))
+		return
+	}
+
+	heartbeat := time.NewTicker(h.cfg.Heartbeat)
+	defer heartbeat.Stop()
+
+	limiter := newLimiter(h.cfg.RateLimit)
+	dropped := 0
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.stopping:
+			return
+		case <-heartbeat.C:
+			if err := writeComment(w, "heartbeat", dropped); err != nil {
+				return
+			}
+			dropped = 0
+		case message, ok := <-subscription.Messages():
+			if !ok {
+				return
+			}
+			if !f.matches(message) {
+				h.metrics.Events.WithLabelValues("filtered").Inc()
+				continue
+			}
+			if !limiter.allow(time.Now()) {
+				h.metrics.Events.WithLabelValues("dropped").Inc()
+				dropped++
+				continue
+			}
+			if dropped > 0 {
+				if err := writeComment(w, "rate limited", dropped); err != nil {
+					return
+				}
+				dropped = 0
+			}
+			if err := writeEvent(w, message); err != nil {
+				h.logger.Debug("Failed to write event to stream", zap.Error(err))
+				return
+			}
+			h.metrics.Events.WithLabelValues("sent").Inc()
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// authorized reports whether the request carries one of the configured API
+// keys, as X-API-Key or as a bearer token
+func (h *Handler) authorized(r *http.Request) bool {
+	if !h.apiKeys.Enabled {
+		return false
+	}
+	key := r.Header.Get("X-API-Key")
+	if key == "" {
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			key = token
+		}
+	}
+	if key == "" {
+		return false
+	}
+
+	authorized := false
+	for _, candidate := range h.apiKeys.Keys {
+		if candidate != "" && subtle.ConstantTimeCompare([]byte(key), []byte(candidate)) == 1 {
+			authorized = true
+		}
+	}
+	return authorized
+}
+
+// matchTopics returns the topics matching any of patterns, excluding Kafka's
+// internal topics
+func (h *Handler) matchTopics(ctx context.Context, patterns []string) ([]string, error) {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%s: %w", pattern, err)
+		}
+	}
+
+	available, err := h.subscriber.ListTopics(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var topics []string
+	for _, topic := range available {
+		if strings.HasPrefix(topic, "__") {
+			continue
+		}
+		for _, pattern := range patterns {
+			if matched, _ := path.Match(pattern, topic); matched {
+				topics = append(topics, topic)
+				break
+			}
+		}
+	}
+	sort.Strings(topics)
+	return topics, nil
+}
+
+// writeEvent writes message as an SSE frame identified by the event ID
+func writeEvent(w io.Writer, message *kafka.Message) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	if message.ID != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", strings.NewReplacer("\n", "", "\r", "").Replace(message.ID)); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+	return err
+}
+
+// writeComment writes an SSE comment, which clients ignore, reporting the
+// events dropped by the rate limit
+func writeComment(w io.Writer, comment string, dropped int) error {
+	if dropped > 0 {
+		comment = fmt.Sprintf("%s, %d events dropped by the rate limit", comment, dropped)
+	}
+	_, err := fmt.Fprintf(w, ": %s\n\n", comment)
+	return err
+}
+
+// listParam splits comma-separated query values
+func listParam(values []string) []string {
+	var list []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
+func setOf(values []string) map[string]bool {
+	if len(values) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+func randomID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// respondError writes an error in the service's response envelope, before a
+// stream starts
+func respondError(w http.ResponseWriter, statusCode int, message string, err error) {
+	response := map[string]interface{}{
+		"success":   false,
+		"message":   message,
+		"timestamp": time.Now(),
+	}
+	if err != nil {
+		response["error"] = err.Error()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// limiter is a token bucket allowing rate events per second, in bursts of up
+// to a second's worth
+type limiter struct {
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newLimiter(rate float64) *limiter {
+	return &limiter{rate: rate, tokens: burst(rate)}
+}
+
+func burst(rate float64) float64 {
+	if rate < 1 {
+		return 1
+	}
+	return rate
+}
+
+func (l *limiter) allow(now time.Time) bool {
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if ceiling := burst(l.rate); l.tokens > ceiling {
+			l.tokens = ceiling
+		}
+	}
+	l.last = now
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// Metrics contains the Prometheus metrics of event streams
+type Metrics struct {
+	Active prometheus.Gauge
+	Events *prometheus.CounterVec
+}
+
+// NewMetrics creates the stream metrics and registers them with reg
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		Active: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "eventbus_streams_active",
+			Help: "Number of open event streams",
+		}),
+		Events: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "eventbus_stream_events_total",
+			Help: "Total number of events read by event streams, by outcome (sent, filtered, dropped)",
+		}, []string{"status"}),
+	}
+
+	reg.MustRegister(m.Active, m.Events)
+	return m
+}
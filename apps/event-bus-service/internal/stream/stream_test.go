@@ -0,0 +1,275 @@
+package stream
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/kafka"
+)
+
+const testAPIKey = "stream-secret"
+
+// fakeSubscription is fed by the test instead of a consumer group
+type fakeSubscription struct {
+	groupID  string
+	topics   []string
+	messages chan *kafka.Message
+	closed   chan struct{}
+	once     sync.Once
+}
+
+func (s *fakeSubscription) Messages() <-chan *kafka.Message {
+	return s.messages
+}
+
+func (s *fakeSubscription) Close() error {
+	s.once.Do(func() { close(s.closed) })
+	return nil
+}
+
+// fakeSubscriber hands out fake subscriptions and reports each on subscribed
+type fakeSubscriber struct {
+	topics     []string
+	subscribed chan *fakeSubscription
+}
+
+func (s *fakeSubscriber) ListTopics(context.Context) ([]string, error) {
+	return s.topics, nil
+}
+
+func (s *fakeSubscriber) Subscribe(_ context.Context, groupID string, topics []string) (kafka.Subscription, error) {
+	subscription := &fakeSubscription{
+		groupID:  groupID,
+		topics:   topics,
+		messages: make(chan *kafka.Message),
+		closed:   make(chan struct{}),
+	}
+	s.subscribed <- subscription
+	return subscription, nil
+}
+
+func newTestServer(t *testing.T, stream config.StreamConfig) (*httptest.Server, *fakeSubscriber, *Metrics) {
+	t.Helper()
+
+	cfg := &config.Config{
+		Server:   config.ServerConfig{Stream: stream},
+		Security: config.SecurityConfig{APIKeys: config.APIKeysConfig{Enabled: true, Keys: map[string]string{"cli": testAPIKey}}},
+	}
+	subscriber := &fakeSubscriber{
+		topics:     []string{"__consumer_offsets", "app.form.created", "app.form.updated", "app.response.created"},
+		subscribed: make(chan *fakeSubscription, 4),
+	}
+	metrics := NewMetrics(prometheus.NewRegistry())
+	handler := NewHandler(cfg, subscriber, metrics, nil)
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(func() {
+		handler.Close()
+		server.Close()
+	})
+	return server, subscriber, metrics
+}
+
+func streamConfig() config.StreamConfig {
+	return config.StreamConfig{Enabled: true, MaxStreams: 2, RateLimit: 1000, Heartbeat: time.Hour, GroupPrefix: "event-bus-stream"}
+}
+
+func openStream(t *testing.T, ctx context.Context, url string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req.Header.Set("Authorization", "Bearer "+testAPIKey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+// frameReader reads SSE frames, each returned as its lines
+type frameReader struct {
+	scanner *bufio.Scanner
+}
+
+func (r *frameReader) next(t *testing.T) []string {
+	t.Helper()
+	var lines []string
+	for r.scanner.Scan() {
+		line := r.scanner.Text()
+		if line == "" {
+			return lines
+		}
+		lines = append(lines, line)
+	}
+	t.Fatalf("stream ended: %v", r.scanner.Err())
+	return nil
+}
+
+func waitSubscribed(t *testing.T, subscriber *fakeSubscriber) *fakeSubscription {
+	t.Helper()
+	select {
+	case subscription := <-subscriber.subscribed:
+		return subscription
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream did not subscribe")
+		return nil
+	}
+}
+
+func TestStreamRequiresAPIKey(t *testing.T) {
+	server, _, _ := newTestServer(t, streamConfig())
+
+	for _, header := range []http.Header{
+		{},
+		{"X-Api-Key": {"wrong"}},
+		{"Authorization": {"Bearer wrong"}},
+	} {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"?topics=app.form.*", nil)
+		req.Header = header
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("headers %v: status = %d, want 401", header, resp.StatusCode)
+		}
+	}
+}
+
+func TestStreamDeliversMatchingEvents(t *testing.T) {
+	server, subscriber, metrics := newTestServer(t, streamConfig())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	resp := openStream(t, ctx, server.URL+"?topics=app.form.*&event_types=form.created,form.updated")
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("status = %d, content type %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	subscription := waitSubscribed(t, subscriber)
+	if want := []string{"app.form.created", "app.form.updated"}; !reflect.DeepEqual(subscription.topics, want) {
+		t.Errorf("subscribed to %v, want %v", subscription.topics, want)
+	}
+	if !strings.HasPrefix(subscription.groupID, "event-bus-stream-") {
+		t.Errorf("group ID %q is not ephemeral", subscription.groupID)
+	}
+
+	frames := &frameReader{scanner: bufio.NewScanner(resp.Body)}
+	if frame := frames.next(t); frame[0] != ": streaming app.form.created, app.form.updated" {
+		t.Errorf("first frame = %v", frame)
+	}
+
+	subscription.messages <- &kafka.Message{ID: "event_1", EventType: "form.deleted", Topic: "app.form.deleted"}
+	subscription.messages <- &kafka.Message{ID: "event_2", EventType: "form.created", Topic: "app.form.created", Data: map[string]interface{}{"form_id": "f1"}}
+
+	frame := frames.next(t)
+	if len(frame) != 2 || frame[0] != "id: event_2" || !strings.HasPrefix(frame[1], "data: ") {
+		t.Fatalf("frame = %v, want the form.created event", frame)
+	}
+	var message kafka.Message
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(frame[1], "data: ")), &message); err != nil || message.EventType != "form.created" {
+		t.Errorf("data = %s: %v", frame[1], err)
+	}
+	if got := testutil.ToFloat64(metrics.Events.WithLabelValues("filtered")); got != 1 {
+		t.Errorf("filtered = %v, want 1", got)
+	}
+
+	// Disconnecting deregisters the consumer
+	cancel()
+	select {
+	case <-subscription.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription not closed after the client disconnected")
+	}
+}
+
+func TestStreamHeartbeatAndRateLimit(t *testing.T) {
+	cfg := streamConfig()
+	cfg.RateLimit = 1
+	cfg.Heartbeat = 50 * time.Millisecond
+	server, subscriber, metrics := newTestServer(t, cfg)
+
+	resp := openStream(t, context.Background(), server.URL+"?topics=app.response.created")
+	defer resp.Body.Close()
+	subscription := waitSubscribed(t, subscriber)
+	frames := &frameReader{scanner: bufio.NewScanner(resp.Body)}
+	frames.next(t)
+
+	for i := 0; i < 4; i++ {
+		subscription.messages <- &kafka.Message{ID: "event", EventType: "response.created"}
+	}
+	if frame := frames.next(t); frame[0] != "id: event" {
+		t.Fatalf("frame = %v, want the first event", frame)
+	}
+
+	// Dropped events are reported by the following comments, then heartbeats
+	// carry on while the stream is idle
+	dropped := 0
+	for dropped < 3 {
+		frame := frames.next(t)
+		var n int
+		if _, err := fmt.Sscanf(frame[0], ": heartbeat, %d events dropped by the rate limit", &n); err != nil {
+			t.Fatalf("frame = %v, want a heartbeat reporting dropped events", frame)
+		}
+		dropped += n
+	}
+	if frame := frames.next(t); frame[0] != ": heartbeat" {
+		t.Errorf("frame = %v, want a heartbeat", frame)
+	}
+	if got := testutil.ToFloat64(metrics.Events.WithLabelValues("dropped")); got != 3 {
+		t.Errorf("dropped = %v, want 3", got)
+	}
+}
+
+func TestStreamLimitsConcurrentStreams(t *testing.T) {
+	server, subscriber, metrics := newTestServer(t, streamConfig())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for i := 0; i < 2; i++ {
+		resp := openStream(t, ctx, server.URL+"?topics=app.form.created")
+		defer resp.Body.Close()
+		waitSubscribed(t, subscriber)
+	}
+	if got := testutil.ToFloat64(metrics.Active); got != 2 {
+		t.Errorf("active streams = %v, want 2", got)
+	}
+
+	resp := openStream(t, context.Background(), server.URL+"?topics=app.form.created")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want 429", resp.StatusCode)
+	}
+}
+
+func TestStreamRejectsUnknownTopics(t *testing.T) {
+	server, _, _ := newTestServer(t, streamConfig())
+
+	tests := map[string]int{
+		"":                      http.StatusBadRequest,
+		"?topics=[":             http.StatusBadRequest,
+		"?topics=app.billing.*": http.StatusNotFound,
+		"?topics=__consumer_*":  http.StatusNotFound,
+	}
+	for query, status := range tests {
+		resp := openStream(t, context.Background(), server.URL+query)
+		resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Errorf("%q: status = %d, want %d", query, resp.StatusCode, status)
+		}
+	}
+}
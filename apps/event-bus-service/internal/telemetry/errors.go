@@ -0,0 +1,103 @@
+// Package telemetry provides comprehensive observability implementation
+// This file implements the error reporter of the Kafka consumers and
+// processors, chosen by observability.errors.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/shared/errreport"
+)
+
+// ProviderOTel reports errors as span events of the traces exported
+const ProviderOTel = "otel"
+
+// NewErrorReporter creates the error reporter of observability.errors,
+// rate-limited per fingerprint
+func NewErrorReporter(cfg *config.Config) (errreport.Reporter, error) {
+	errs := cfg.Observability.Errors
+	if errs.Provider == ProviderOTel {
+		return errreport.Limit(NewOTelReporter(), errreport.LimitConfig{
+			Burst:      errs.Burst,
+			Interval:   errs.Interval,
+			SampleRate: errs.SampleRate,
+		}), nil
+	}
+	return errreport.New(errreport.Config{
+		Provider:    errs.Provider,
+		Service:     "event-bus-service",
+		Environment: cfg.Environment,
+		Release:     fmt.Sprintf("event-bus-service@%s", cfg.Version),
+		DSN:         errs.DSN,
+		Burst:       errs.Burst,
+		Interval:    errs.Interval,
+		SampleRate:  errs.SampleRate,
+	})
+}
+
+// OTelReporter records the errors on the span of their context, or on a
+// span of their own when it has none, so they are found with the trace of
+// the message that failed
+type OTelReporter struct {
+	tracer trace.Tracer
+}
+
+// NewOTelReporter creates an OTelReporter on the global tracer provider
+func NewOTelReporter() *OTelReporter {
+	return &OTelReporter{tracer: otel.Tracer("event-bus-service/errors")}
+}
+
+// Report records the event on the span
+func (r *OTelReporter) Report(ctx context.Context, event *errreport.Event) string {
+	if event.ID == "" {
+		event.ID = errreport.NewID()
+	}
+
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		_, span = r.tracer.Start(ctx, "error")
+		defer span.End()
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("error.id", event.ID),
+		attribute.Bool("error.panic", event.Panic),
+	}
+	if event.Stack != "" {
+		attrs = append(attrs, attribute.String("exception.stacktrace", event.Stack))
+	}
+	if m := event.Message; m != nil {
+		attrs = append(attrs,
+			attribute.String("messaging.message.id", m.ID),
+			attribute.String("messaging.destination.name", m.Topic),
+			attribute.Int("messaging.kafka.destination.partition", int(m.Partition)),
+			attribute.Int64("messaging.kafka.message.offset", m.Offset),
+			attribute.String("messaging.kafka.consumer.group", m.Group),
+			attribute.String("event.type", m.EventType),
+		)
+	}
+	for key, value := range event.Tags {
+		attrs = append(attrs, attribute.String(key, value))
+	}
+
+	err := event.Err
+	if err == nil {
+		err = fmt.Errorf("unknown error")
+	}
+	span.RecordError(err, trace.WithAttributes(attrs...))
+	span.SetStatus(codes.Error, err.Error())
+	return event.ID
+}
+
+// Flush returns at once; the spans are flushed with the tracer provider
+func (r *OTelReporter) Flush(time.Duration) bool {
+	return true
+}
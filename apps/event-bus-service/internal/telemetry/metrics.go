@@ -5,6 +5,7 @@ package telemetry
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"runtime"
@@ -20,40 +21,41 @@ import (
 
 // MetricsProvider manages Prometheus metrics collection
 type MetricsProvider struct {
-	config   *config.Config
-	logger   *zap.Logger
-	registry *prometheus.Registry
-	server   *http.Server
+	config     *config.Config
+	logger     *zap.Logger
+	registerer prometheus.Registerer
+	gatherer   prometheus.Gatherer
+	server     *http.Server
 
 	// Application metrics
-	requestsTotal     prometheus.CounterVec
-	requestDuration   prometheus.HistogramVec
+	requestsTotal     *prometheus.CounterVec
+	requestDuration   *prometheus.HistogramVec
 	activeConnections prometheus.Gauge
 
 	// Event Bus specific metrics
-	eventsProduced        prometheus.CounterVec
-	eventsConsumed        prometheus.CounterVec
-	eventProcessingTime   prometheus.HistogramVec
-	eventProcessingErrors prometheus.CounterVec
-	kafkaOperations       prometheus.CounterVec
-	kafkaConnectionStatus prometheus.GaugeVec
+	eventsProduced        *prometheus.CounterVec
+	eventsConsumed        *prometheus.CounterVec
+	eventProcessingTime   *prometheus.HistogramVec
+	eventProcessingErrors *prometheus.CounterVec
+	kafkaOperations       *prometheus.CounterVec
+	kafkaConnectionStatus *prometheus.GaugeVec
 
 	// CDC specific metrics
-	debeziumConnectorStatus prometheus.GaugeVec
-	cdcEventsProcessed      prometheus.CounterVec
-	cdcLagSeconds           prometheus.GaugeVec
+	debeziumConnectorStatus *prometheus.GaugeVec
+	cdcEventsProcessed      *prometheus.CounterVec
+	cdcLagSeconds           *prometheus.GaugeVec
 
 	// System metrics
 	goRoutines  prometheus.Gauge
-	memoryUsage prometheus.GaugeVec
+	memoryUsage *prometheus.GaugeVec
 	cpuUsage    prometheus.Gauge
-	diskUsage   prometheus.GaugeVec
+	diskUsage   *prometheus.GaugeVec
 
 	// Business metrics
-	formsProcessed     prometheus.CounterVec
-	responsesProcessed prometheus.CounterVec
-	analyticsEvents    prometheus.CounterVec
-	errorsByType       prometheus.CounterVec
+	formsProcessed     *prometheus.CounterVec
+	responsesProcessed *prometheus.CounterVec
+	analyticsEvents    *prometheus.CounterVec
+	errorsByType       *prometheus.CounterVec
 }
 
 // MetricsConfig defines metrics configuration
@@ -67,12 +69,28 @@ type MetricsConfig struct {
 	Percentiles []float64         `json:"percentiles"`
 }
 
-// NewMetricsProvider creates a new Prometheus metrics provider
+// NewMetricsProvider creates a new Prometheus metrics provider with a
+// registry of its own
 func NewMetricsProvider(cfg *config.Config, logger *zap.Logger) (*MetricsProvider, error) {
+	return NewMetricsProviderFor(cfg, logger, prometheus.NewRegistry())
+}
+
+// NewMetricsProviderFor creates a Prometheus metrics provider registering its
+// collectors with reg, so several components can share one registry. When a
+// collector is already registered with reg, the provider records into the
+// existing one. The metrics endpoint serves reg when it is also a Gatherer,
+// and the default gatherer otherwise.
+func NewMetricsProviderFor(cfg *config.Config, logger *zap.Logger, reg prometheus.Registerer) (*MetricsProvider, error) {
+	gatherer, ok := reg.(prometheus.Gatherer)
+	if !ok {
+		gatherer = prometheus.DefaultGatherer
+	}
+
 	mp := &MetricsProvider{
-		config:   cfg,
-		logger:   logger,
-		registry: prometheus.NewRegistry(),
+		config:     cfg,
+		logger:     logger,
+		registerer: reg,
+		gatherer:   gatherer,
 	}
 
 	if err := mp.initializeMetrics(); err != nil {
@@ -96,7 +114,7 @@ func (mp *MetricsProvider) initializeMetrics() error {
 	cdcLabels := append(commonLabels, "connector", "table", "operation")
 
 	// HTTP metrics
-	mp.requestsTotal = *prometheus.NewCounterVec(
+	mp.requestsTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: "event_bus",
 			Subsystem: "http",
@@ -106,7 +124,7 @@ func (mp *MetricsProvider) initializeMetrics() error {
 		httpLabels,
 	)
 
-	mp.requestDuration = *prometheus.NewHistogramVec(
+	mp.requestDuration = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Namespace: "event_bus",
 			Subsystem: "http",
@@ -127,7 +145,7 @@ func (mp *MetricsProvider) initializeMetrics() error {
 	)
 
 	// Event processing metrics
-	mp.eventsProduced = *prometheus.NewCounterVec(
+	mp.eventsProduced = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: "event_bus",
 			Subsystem: "events",
@@ -137,7 +155,7 @@ func (mp *MetricsProvider) initializeMetrics() error {
 		eventLabels,
 	)
 
-	mp.eventsConsumed = *prometheus.NewCounterVec(
+	mp.eventsConsumed = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: "event_bus",
 			Subsystem: "events",
@@ -147,7 +165,7 @@ func (mp *MetricsProvider) initializeMetrics() error {
 		eventLabels,
 	)
 
-	mp.eventProcessingTime = *prometheus.NewHistogramVec(
+	mp.eventProcessingTime = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Namespace: "event_bus",
 			Subsystem: "events",
@@ -158,7 +176,7 @@ func (mp *MetricsProvider) initializeMetrics() error {
 		eventLabels,
 	)
 
-	mp.eventProcessingErrors = *prometheus.NewCounterVec(
+	mp.eventProcessingErrors = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: "event_bus",
 			Subsystem: "events",
@@ -169,7 +187,7 @@ func (mp *MetricsProvider) initializeMetrics() error {
 	)
 
 	// Kafka metrics
-	mp.kafkaOperations = *prometheus.NewCounterVec(
+	mp.kafkaOperations = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: "event_bus",
 			Subsystem: "kafka",
@@ -179,7 +197,7 @@ func (mp *MetricsProvider) initializeMetrics() error {
 		kafkaLabels,
 	)
 
-	mp.kafkaConnectionStatus = *prometheus.NewGaugeVec(
+	mp.kafkaConnectionStatus = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "event_bus",
 			Subsystem: "kafka",
@@ -190,7 +208,7 @@ func (mp *MetricsProvider) initializeMetrics() error {
 	)
 
 	// CDC/Debezium metrics
-	mp.debeziumConnectorStatus = *prometheus.NewGaugeVec(
+	mp.debeziumConnectorStatus = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "event_bus",
 			Subsystem: "cdc",
@@ -200,7 +218,7 @@ func (mp *MetricsProvider) initializeMetrics() error {
 		[]string{"connector_name", "task_id"},
 	)
 
-	mp.cdcEventsProcessed = *prometheus.NewCounterVec(
+	mp.cdcEventsProcessed = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: "event_bus",
 			Subsystem: "cdc",
@@ -210,7 +228,7 @@ func (mp *MetricsProvider) initializeMetrics() error {
 		cdcLabels,
 	)
 
-	mp.cdcLagSeconds = *prometheus.NewGaugeVec(
+	mp.cdcLagSeconds = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "event_bus",
 			Subsystem: "cdc",
@@ -230,7 +248,7 @@ func (mp *MetricsProvider) initializeMetrics() error {
 		},
 	)
 
-	mp.memoryUsage = *prometheus.NewGaugeVec(
+	mp.memoryUsage = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "event_bus",
 			Subsystem: "system",
@@ -249,7 +267,7 @@ func (mp *MetricsProvider) initializeMetrics() error {
 		},
 	)
 
-	mp.diskUsage = *prometheus.NewGaugeVec(
+	mp.diskUsage = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "event_bus",
 			Subsystem: "system",
@@ -260,7 +278,7 @@ func (mp *MetricsProvider) initializeMetrics() error {
 	)
 
 	// Business metrics
-	mp.formsProcessed = *prometheus.NewCounterVec(
+	mp.formsProcessed = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: "event_bus",
 			Subsystem: "business",
@@ -270,7 +288,7 @@ func (mp *MetricsProvider) initializeMetrics() error {
 		[]string{"service", "operation", "status"},
 	)
 
-	mp.responsesProcessed = *prometheus.NewCounterVec(
+	mp.responsesProcessed = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: "event_bus",
 			Subsystem: "business",
@@ -280,7 +298,7 @@ func (mp *MetricsProvider) initializeMetrics() error {
 		[]string{"service", "form_id", "status"},
 	)
 
-	mp.analyticsEvents = *prometheus.NewCounterVec(
+	mp.analyticsEvents = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: "event_bus",
 			Subsystem: "business",
@@ -290,7 +308,7 @@ func (mp *MetricsProvider) initializeMetrics() error {
 		[]string{"event_type", "source"},
 	)
 
-	mp.errorsByType = *prometheus.NewCounterVec(
+	mp.errorsByType = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: "event_bus",
 			Subsystem: "errors",
@@ -300,50 +318,115 @@ func (mp *MetricsProvider) initializeMetrics() error {
 		[]string{"error_type", "component", "severity"},
 	)
 
-	// Register all metrics with the registry
-	mp.registerMetrics()
+	// Register all metrics with the registerer
+	if err := mp.registerMetrics(); err != nil {
+		return err
+	}
 
 	mp.logger.Info("Prometheus metrics initialized successfully")
 	return nil
 }
 
-// registerMetrics registers all metrics with the Prometheus registry
-func (mp *MetricsProvider) registerMetrics() {
+// registerMetrics registers all metrics with the Prometheus registerer
+func (mp *MetricsProvider) registerMetrics() error {
+	var err error
+
 	// HTTP metrics
-	mp.registry.MustRegister(&mp.requestsTotal)
-	mp.registry.MustRegister(&mp.requestDuration)
-	mp.registry.MustRegister(mp.activeConnections)
+	if mp.requestsTotal, err = registerShared(mp.registerer, mp.requestsTotal); err != nil {
+		return err
+	}
+	if mp.requestDuration, err = registerShared(mp.registerer, mp.requestDuration); err != nil {
+		return err
+	}
+	if mp.activeConnections, err = registerShared(mp.registerer, mp.activeConnections); err != nil {
+		return err
+	}
 
 	// Event metrics
-	mp.registry.MustRegister(&mp.eventsProduced)
-	mp.registry.MustRegister(&mp.eventsConsumed)
-	mp.registry.MustRegister(&mp.eventProcessingTime)
-	mp.registry.MustRegister(&mp.eventProcessingErrors)
+	if mp.eventsProduced, err = registerShared(mp.registerer, mp.eventsProduced); err != nil {
+		return err
+	}
+	if mp.eventsConsumed, err = registerShared(mp.registerer, mp.eventsConsumed); err != nil {
+		return err
+	}
+	if mp.eventProcessingTime, err = registerShared(mp.registerer, mp.eventProcessingTime); err != nil {
+		return err
+	}
+	if mp.eventProcessingErrors, err = registerShared(mp.registerer, mp.eventProcessingErrors); err != nil {
+		return err
+	}
 
 	// Kafka metrics
-	mp.registry.MustRegister(&mp.kafkaOperations)
-	mp.registry.MustRegister(&mp.kafkaConnectionStatus)
+	if mp.kafkaOperations, err = registerShared(mp.registerer, mp.kafkaOperations); err != nil {
+		return err
+	}
+	if mp.kafkaConnectionStatus, err = registerShared(mp.registerer, mp.kafkaConnectionStatus); err != nil {
+		return err
+	}
 
 	// CDC metrics
-	mp.registry.MustRegister(&mp.debeziumConnectorStatus)
-	mp.registry.MustRegister(&mp.cdcEventsProcessed)
-	mp.registry.MustRegister(&mp.cdcLagSeconds)
+	if mp.debeziumConnectorStatus, err = registerShared(mp.registerer, mp.debeziumConnectorStatus); err != nil {
+		return err
+	}
+	if mp.cdcEventsProcessed, err = registerShared(mp.registerer, mp.cdcEventsProcessed); err != nil {
+		return err
+	}
+	if mp.cdcLagSeconds, err = registerShared(mp.registerer, mp.cdcLagSeconds); err != nil {
+		return err
+	}
 
 	// System metrics
-	mp.registry.MustRegister(mp.goRoutines)
-	mp.registry.MustRegister(&mp.memoryUsage)
-	mp.registry.MustRegister(mp.cpuUsage)
-	mp.registry.MustRegister(&mp.diskUsage)
+	if mp.goRoutines, err = registerShared(mp.registerer, mp.goRoutines); err != nil {
+		return err
+	}
+	if mp.memoryUsage, err = registerShared(mp.registerer, mp.memoryUsage); err != nil {
+		return err
+	}
+	if mp.cpuUsage, err = registerShared(mp.registerer, mp.cpuUsage); err != nil {
+		return err
+	}
+	if mp.diskUsage, err = registerShared(mp.registerer, mp.diskUsage); err != nil {
+		return err
+	}
 
 	// Business metrics
-	mp.registry.MustRegister(&mp.formsProcessed)
-	mp.registry.MustRegister(&mp.responsesProcessed)
-	mp.registry.MustRegister(&mp.analyticsEvents)
-	mp.registry.MustRegister(&mp.errorsByType)
+	if mp.formsProcessed, err = registerShared(mp.registerer, mp.formsProcessed); err != nil {
+		return err
+	}
+	if mp.responsesProcessed, err = registerShared(mp.registerer, mp.responsesProcessed); err != nil {
+		return err
+	}
+	if mp.analyticsEvents, err = registerShared(mp.registerer, mp.analyticsEvents); err != nil {
+		return err
+	}
+	if mp.errorsByType, err = registerShared(mp.registerer, mp.errorsByType); err != nil {
+		return err
+	}
 
 	// Register Go runtime metrics
-	mp.registry.MustRegister(prometheus.NewGoCollector())
-	mp.registry.MustRegister(prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+	if _, err = registerShared(mp.registerer, prometheus.NewGoCollector()); err != nil {
+		return err
+	}
+	if _, err = registerShared(mp.registerer, prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{})); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// registerShared registers c with reg. If an equal collector is already
+// registered, that collector is returned instead so it can be shared.
+func registerShared[T prometheus.Collector](reg prometheus.Registerer, c T) (T, error) {
+	if err := reg.Register(c); err != nil {
+		var alreadyRegistered prometheus.AlreadyRegisteredError
+		if errors.As(err, &alreadyRegistered) {
+			if existing, ok := alreadyRegistered.ExistingCollector.(T); ok {
+				return existing, nil
+			}
+		}
+		return c, fmt.Errorf("failed to register collector: %w", err)
+	}
+	return c, nil
 }
 
 // setupMetricsServer sets up the HTTP server for metrics exposition
@@ -351,7 +434,7 @@ func (mp *MetricsProvider) setupMetricsServer() error {
 	mux := http.NewServeMux()
 
 	// Prometheus metrics endpoint
-	mux.Handle("/metrics", promhttp.HandlerFor(mp.registry, promhttp.HandlerOpts{
+	mux.Handle("/metrics", promhttp.HandlerFor(mp.gatherer, promhttp.HandlerOpts{
 		EnableOpenMetrics: true,
 	}))
 
@@ -375,7 +458,7 @@ func (mp *MetricsProvider) setupMetricsServer() error {
 
 // Handler returns the Prometheus metrics handler
 func (mp *MetricsProvider) Handler() http.Handler {
-	return promhttp.HandlerFor(mp.registry, promhttp.HandlerOpts{
+	return promhttp.HandlerFor(mp.gatherer, promhttp.HandlerOpts{
 		EnableOpenMetrics: true,
 	})
 }
@@ -0,0 +1,35 @@
+package telemetry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+
+	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+)
+
+func TestMetricsProvidersShareRegistry(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	first, err := NewMetricsProviderFor(&config.Config{}, zap.NewNop(), reg)
+	if err != nil {
+		t.Fatalf("failed to create first provider: %v", err)
+	}
+	second, err := NewMetricsProviderFor(&config.Config{}, zap.NewNop(), reg)
+	if err != nil {
+		t.Fatalf("failed to create second provider on the same registry: %v", err)
+	}
+
+	first.RecordHTTPRequest("GET", "/events", 200, time.Millisecond)
+	second.RecordHTTPRequest("GET", "/events", 200, time.Millisecond)
+
+	if got := testutil.CollectAndCount(first.requestsTotal); got != 1 {
+		t.Errorf("expected one shared series, got %d", got)
+	}
+	if first.requestsTotal != second.requestsTotal {
+		t.Error("expected providers to share the registered collector")
+	}
+}
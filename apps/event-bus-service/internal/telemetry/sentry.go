@@ -4,17 +4,21 @@
 package telemetry
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"runtime"
 	"runtime/debug"
 	"strconv"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/Mir00r/X-Form-Backend/services/event-bus-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/shared/errreport"
 )
 
 // ErrorLevel represents error severity levels
@@ -30,9 +34,10 @@ const (
 
 // ErrorProvider manages error tracking and reporting
 type ErrorProvider struct {
-	config  *config.Config
-	logger  *zap.Logger
-	enabled bool
+	config   *config.Config
+	logger   *zap.Logger
+	reporter errreport.Reporter
+	enabled  bool
 }
 
 // ErrorContext provides additional context for error reporting
@@ -70,14 +75,21 @@ type ErrorEvent struct {
 
 // NewErrorProvider creates a new error tracking provider
 func NewErrorProvider(cfg *config.Config, logger *zap.Logger) (*ErrorProvider, error) {
+	reporter, err := NewErrorReporter(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create error reporter: %w", err)
+	}
+
 	ep := &ErrorProvider{
-		config:  cfg,
-		logger:  logger,
-		enabled: true, // Can be configured based on environment
+		config:   cfg,
+		logger:   logger,
+		reporter: reporter,
+		enabled:  true, // Can be configured based on environment
 	}
 
 	ep.logger.Info("Error tracking provider initialized",
 		zap.String("environment", cfg.Environment),
+		zap.String("provider", cfg.Observability.Errors.Provider),
 		zap.Bool("enabled", ep.enabled))
 
 	return ep, nil
@@ -259,9 +271,17 @@ func (ep *ErrorProvider) Recovery() func() {
 	}
 }
 
-// Shutdown gracefully shuts down error tracking
+// Reporter returns the error reporter the events are sent to
+func (ep *ErrorProvider) Reporter() errreport.Reporter {
+	return ep.reporter
+}
+
+// Shutdown gracefully shuts down error tracking, sending the events pending
 func (ep *ErrorProvider) Shutdown() {
 	ep.logger.Info("Shutting down error tracking provider")
+	if !ep.reporter.Flush(5 * time.Second) {
+		ep.logger.Warn("Timed out sending the pending error events")
+	}
 }
 
 // Helper methods
@@ -297,8 +317,12 @@ func (ep *ErrorProvider) createErrorEvent(message string, level ErrorLevel, err
 	return event
 }
 
-// reportError reports the error event (can be extended to send to external services)
+// reportError reports the error event to the error reporter and logs it
 func (ep *ErrorProvider) reportError(event *ErrorEvent) {
+	// The reporter may answer with the ID of an earlier event of the same
+	// error when it is rate-limited
+	event.ID = ep.reporter.Report(context.Background(), ep.reportedEvent(event))
+
 	// Log the error with structured logging
 	logFields := []zap.Field{
 		zap.String("event_id", event.ID),
@@ -341,15 +365,42 @@ func (ep *ErrorProvider) reportError(event *ErrorEvent) {
 	default:
 		ep.logger.Error("Error captured", logFields...)
 	}
+}
+
+// reportedEvent converts the error event to the event of the reporter
+func (ep *ErrorProvider) reportedEvent(event *ErrorEvent) *errreport.Event {
+	reported := &errreport.Event{
+		ID:    event.ID,
+		Time:  event.Timestamp,
+		Err:   errors.New(event.Message),
+		Stack: event.StackTrace,
+		Tags:  map[string]string{"level": string(event.Level)},
+	}
+	if event.Error != "" {
+		reported.Err = errors.New(event.Error)
+	}
 
-	// Here you can add integration with external error tracking services
-	// For example: Sentry, Bugsnag, Rollbar, etc.
-	// ep.sendToExternalService(event)
+	if c := event.Context; c != nil {
+		reported.Panic = c.Operation == "panic_recovery"
+		reported.Fingerprint = strings.Join(c.Fingerprint, "/")
+		reported.Extra = c.Extra
+		for key, value := range c.Tags {
+			reported.Tags[key] = value
+		}
+		if c.Component != "" {
+			reported.Tags["component"] = c.Component
+		}
+		if c.Topic != "" || c.EventType != "" {
+			reported.Message = &errreport.Message{Topic: c.Topic, EventType: c.EventType}
+		}
+	}
+	return reported
 }
 
-// generateEventID generates a unique event ID
+// generateEventID generates a unique event ID, in the format of the IDs of
+// the error trackers
 func (ep *ErrorProvider) generateEventID() string {
-	return fmt.Sprintf("err_%d_%d", time.Now().UnixNano(), os.Getpid())
+	return errreport.NewID()
 }
 
 // getServerName gets the server name for error context
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.uber.org/zap"
@@ -23,6 +24,7 @@ type Provider struct {
 	tracing     *TracerProvider
 	metrics     *MetricsProvider
 	errors      *ErrorProvider
+	registerer  prometheus.Registerer
 	initialized bool
 }
 
@@ -36,11 +38,19 @@ type ProviderConfig struct {
 	EnableErrors   bool   `json:"enable_errors"`
 }
 
-// New creates a new comprehensive telemetry provider
+// New creates a new comprehensive telemetry provider with a metrics
+// registry of its own
 func New(cfg *config.Config, logger *zap.Logger) (*Provider, error) {
+	return NewFor(cfg, logger, prometheus.NewRegistry())
+}
+
+// NewFor creates a telemetry provider registering its metrics with reg, so
+// it can share one registry with other components
+func NewFor(cfg *config.Config, logger *zap.Logger, reg prometheus.Registerer) (*Provider, error) {
 	provider := &Provider{
-		config: cfg,
-		logger: logger,
+		config:     cfg,
+		logger:     logger,
+		registerer: reg,
 	}
 
 	if err := provider.initialize(); err != nil {
@@ -91,7 +101,7 @@ func (p *Provider) initializeTracing() error {
 
 // initializeMetrics sets up metrics collection
 func (p *Provider) initializeMetrics() error {
-	metricsProvider, err := NewMetricsProvider(p.config, p.logger)
+	metricsProvider, err := NewMetricsProviderFor(p.config, p.logger, p.registerer)
 	if err != nil {
 		return fmt.Errorf("failed to create metrics provider: %w", err)
 	}
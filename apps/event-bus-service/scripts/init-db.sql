@@ -66,6 +66,121 @@ CREATE TABLE IF NOT EXISTS public.analytics (
     user_agent TEXT
 );
 
+-- Response events projection: one row per answer of each submitted response,
+-- written idempotently from form.response.created events keyed on event ID.
+-- form.response.updated events replace the rows of the edited response, so
+-- only its latest revision is kept.
+CREATE TABLE IF NOT EXISTS public.response_events (
+    event_id VARCHAR(255) NOT NULL,
+    question_id VARCHAR(255) NOT NULL,
+    response_id VARCHAR(255) NOT NULL,
+    form_id VARCHAR(255) NOT NULL,
+    form_version INTEGER NOT NULL DEFAULT 1,
+    revision INTEGER NOT NULL DEFAULT 1,
+    answer JSONB,
+    answers_hash VARCHAR(128) NOT NULL,
+    respondent_id VARCHAR(255),
+    is_anonymous BOOLEAN NOT NULL DEFAULT FALSE,
+    submitted_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    presented_order JSONB,
+    is_test BOOLEAN NOT NULL DEFAULT FALSE,
+    question_key VARCHAR(64),
+    projected_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (event_id, question_id)
+);
+
+-- Response timings projection: the per-question timing beacons of each
+-- submission, { focus_ms, revisits } by question ID, checked and capped by
+-- the projection. Kept apart from response_events and out of exports unless
+-- asked for.
+CREATE TABLE IF NOT EXISTS public.response_timings (
+    response_id VARCHAR(255) PRIMARY KEY,
+    form_id VARCHAR(255) NOT NULL,
+    timings JSONB NOT NULL,
+    submitted_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    is_test BOOLEAN NOT NULL DEFAULT FALSE,
+    projected_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Funnel projection: the stages reached by the sessions of respondents with
+-- forms, each counted once, and the daily counts of each form
+CREATE TABLE IF NOT EXISTS public.form_funnel_sessions (
+    form_id VARCHAR(255) NOT NULL,
+    session_id VARCHAR(255) NOT NULL,
+    stage VARCHAR(16) NOT NULL,
+    day DATE NOT NULL,
+    PRIMARY KEY (form_id, session_id, stage)
+);
+
+CREATE TABLE IF NOT EXISTS public.form_funnel_daily (
+    form_id VARCHAR(255) NOT NULL,
+    day DATE NOT NULL,
+    views BIGINT NOT NULL DEFAULT 0,
+    starts BIGINT NOT NULL DEFAULT 0,
+    submissions BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (form_id, day)
+);
+
+-- Event store: a copy of the consumed events searched by POST /events/filter
+-- and purged after the configured retention
+CREATE TABLE IF NOT EXISTS public.event_store (
+    event_id VARCHAR(255) PRIMARY KEY,
+    topic VARCHAR(255) NOT NULL,
+    event_type VARCHAR(255) NOT NULL DEFAULT '',
+    source VARCHAR(255) NOT NULL DEFAULT '',
+    subject VARCHAR(255) NOT NULL DEFAULT '',
+    correlation_id VARCHAR(255) NOT NULL DEFAULT '',
+    payload JSONB,
+    headers JSONB,
+    event_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    stored_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Scheduled events: events published with a deliver_at, published by the
+-- scheduler leader once due
+CREATE TABLE IF NOT EXISTS public.scheduled_events (
+    id VARCHAR(255) PRIMARY KEY,
+    event_type VARCHAR(255) NOT NULL,
+    source VARCHAR(255) NOT NULL,
+    subject VARCHAR(255) NOT NULL DEFAULT '',
+    topic VARCHAR(255) NOT NULL,
+    message_key VARCHAR(255) NOT NULL DEFAULT '',
+    headers JSONB,
+    data JSONB NOT NULL,
+    deliver_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    claimed_by VARCHAR(255),
+    claimed_until TIMESTAMP WITH TIME ZONE,
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    kafka_partition INTEGER,
+    kafka_offset BIGINT,
+    cancelled_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Event type catalog: services registered as producers and consumers, and
+-- the event types seen in traffic
+CREATE TABLE IF NOT EXISTS public.catalog_registrations (
+    service VARCHAR(255) PRIMARY KEY,
+    produces TEXT[] NOT NULL DEFAULT '{}',
+    consumes TEXT[] NOT NULL DEFAULT '{}',
+    schema_ref TEXT NOT NULL DEFAULT '',
+    team VARCHAR(255) NOT NULL DEFAULT '',
+    source VARCHAR(20) NOT NULL,
+    registered_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    heartbeat_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS public.catalog_event_types (
+    event_type VARCHAR(255) PRIMARY KEY,
+    last_seen TIMESTAMP WITH TIME ZONE NOT NULL,
+    sample JSONB
+);
+
 -- Create indexes for better performance
 CREATE INDEX IF NOT EXISTS idx_forms_created_by ON public.forms(created_by);
 CREATE INDEX IF NOT EXISTS idx_forms_status ON public.forms(status);
@@ -81,6 +196,20 @@ CREATE INDEX IF NOT EXISTS idx_analytics_entity ON public.analytics(entity_type,
 CREATE INDEX IF NOT EXISTS idx_analytics_user_id ON public.analytics(user_id);
 CREATE INDEX IF NOT EXISTS idx_analytics_created_at ON public.analytics(created_at);
 
+CREATE INDEX IF NOT EXISTS idx_response_events_form_question ON public.response_events(form_id, question_id);
+CREATE INDEX IF NOT EXISTS idx_response_events_response_id ON public.response_events(response_id);
+CREATE INDEX IF NOT EXISTS idx_response_events_submitted_at ON public.response_events(submitted_at);
+CREATE INDEX IF NOT EXISTS idx_response_events_respondent_id ON public.response_events(respondent_id);
+CREATE INDEX IF NOT EXISTS idx_response_timings_form_id ON public.response_timings(form_id, submitted_at);
+CREATE INDEX IF NOT EXISTS idx_form_funnel_sessions_day ON public.form_funnel_sessions(day);
+
+CREATE INDEX IF NOT EXISTS idx_event_store_event_type ON public.event_store(event_type);
+CREATE INDEX IF NOT EXISTS idx_event_store_source ON public.event_store(source);
+CREATE INDEX IF NOT EXISTS idx_event_store_event_time ON public.event_store(event_time);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_events_due ON public.scheduled_events(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_scheduled_events_pending ON public.scheduled_events(deliver_at, id) WHERE status = 'pending';
+
 -- Create GIN indexes for JSONB columns
 CREATE INDEX IF NOT EXISTS idx_forms_schema_gin ON public.forms USING GIN(schema);
 CREATE INDEX IF NOT EXISTS idx_forms_settings_gin ON public.forms USING GIN(settings);
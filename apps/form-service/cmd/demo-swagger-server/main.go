@@ -61,13 +61,16 @@ func NewApplicationContainer() (*ApplicationContainer, error) {
 	startTime := time.Now()
 
 	// Load configuration
-	cfg := config.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
 
 	// Skip database initialization for demo
 	// TODO: Uncomment for full functionality
 	/*
 		// Initialize database connection
-		db, err := database.Connect(cfg.DatabaseURL)
+		db, err := database.Connect(cfg.DatabaseURL, cfg.Database)
 		if err != nil {
 			return nil, fmt.Errorf("failed to connect to database: %w", err)
 		}
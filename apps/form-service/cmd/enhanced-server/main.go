@@ -14,15 +14,22 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.uber.org/zap"
 
 	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/application"
 	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/config"
 	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/database"
 	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/handlers"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/health"
 	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/infrastructure"
 	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/integration"
 	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/middleware"
 	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/validation"
+	"github.com/Mir00r/X-Form-Backend/shared/observability"
+
+	_ "github.com/Mir00r/X-Form-Backend/services/form-service/docs"
 )
 
 // EnhancedApplicationContainer demonstrates microservices best practices
@@ -33,7 +40,11 @@ type EnhancedApplicationContainer struct {
 	ResponseHandler *handlers.ResponseHandler
 	FormValidator   *validation.FormValidator
 	FormMapper      *integration.SimplifiedFormMapper
-	StartTime       time.Time
+	Readiness       *health.Checker
+	// Observability serves the metrics, traces and logs of the service
+	// under the names the other services use
+	Observability *observability.Provider
+	StartTime     time.Time
 }
 
 // NewEnhancedApplicationContainer creates application dependencies following microservices best practices
@@ -41,17 +52,48 @@ func NewEnhancedApplicationContainer() (*EnhancedApplicationContainer, error) {
 	startTime := time.Now()
 
 	// Load configuration
-	cfg := config.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	// Metrics, traces and logs shared with the other services, tagged with
+	// the environment of the configuration
+	obsConfig := observability.DefaultConfig("form-service")
+	obsConfig.Environment = cfg.Environment
+	obs, err := observability.New(obsConfig, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize observability: %w", err)
+	}
 
 	// Initialize database connection
-	db, err := database.Connect(cfg.DatabaseURL)
+	db, err := database.Connect(cfg.DatabaseURL, cfg.Database)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
+	if err := database.RegisterMetrics(obs.Metrics().Registry(), db); err != nil {
+		return nil, fmt.Errorf("failed to register database metrics: %w", err)
+	}
 
-	// Auto-migrate database schema
-	if err := database.Migrate(db); err != nil {
-		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	// Production migrates with `form-service migrate up` before rolling out
+	// a release, so the server only checks that the schema is not behind
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	if cfg.AutoMigrate {
+		if err := migrator.Up(context.Background()); err != nil {
+			return nil, fmt.Errorf("failed to migrate database: %w", err)
+		}
+	} else if err := migrator.CheckVersion(context.Background()); err != nil {
+		return nil, err
+	}
+
+	// Readiness reflects the database the requests need
+	readiness := health.NewChecker(cfg.ReadinessTimeout)
+	readiness.AddDetailed("database", health.Database(db), health.DatabasePool(db))
+	if cfg.ReadinessCheckMigrations {
+		readiness.Add("migrations", health.Migrations(migrator.Pending))
 	}
 
 	// Initialize repositories (Infrastructure Layer)
@@ -75,6 +117,8 @@ func NewEnhancedApplicationContainer() (*EnhancedApplicationContainer, error) {
 		ResponseHandler: responseHandler,
 		FormValidator:   formValidator,
 		FormMapper:      formMapper,
+		Readiness:       readiness,
+		Observability:   obs,
 		StartTime:       startTime,
 	}, nil
 }
@@ -122,8 +166,9 @@ func setupEnhancedRouter(container *EnhancedApplicationContainer) *gin.Engine {
 	// Correlation ID middleware (must be first for request tracing)
 	router.Use(handlers.CorrelationIDMiddleware())
 
-	// Request metrics middleware for observability
-	router.Use(handlers.RequestMetricsMiddleware())
+	// Request metrics, traces and error reports shared with the other
+	// services
+	router.Use(container.Observability.GinMiddleware())
 
 	// Security headers middleware
 	router.Use(handlers.SecurityHeadersMiddleware())
@@ -170,8 +215,8 @@ func setupEnhancedRouter(container *EnhancedApplicationContainer) *gin.Engine {
 			"endpoints": map[string]string{
 				"api":           "/api/v1",
 				"health":        "/api/v1/health",
-				"documentation": "/api/v1/docs",
-				"swagger":       "/api/v1/swagger",
+				"documentation": "/swagger/index.html",
+				"swagger":       "/swagger/doc.json",
 			},
 			"timestamp": time.Now().UTC(),
 		})
@@ -181,9 +226,14 @@ func setupEnhancedRouter(container *EnhancedApplicationContainer) *gin.Engine {
 	// API Documentation
 	// =============================================================================
 
-	// Swagger documentation endpoint
+	// Prometheus scrapes the http_server_* metrics, named as in the other
+	// services, with the database pool and the runtime
+	router.GET("/metrics", gin.WrapH(container.Observability.Metrics().Handler()))
+
+	// Swagger documentation generated from the handler annotations
+	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	router.GET("/docs", func(c *gin.Context) {
-		c.Redirect(http.StatusPermanentRedirect, "/api/v1/docs")
+		c.Redirect(http.StatusPermanentRedirect, "/swagger/index.html")
 	})
 
 	// =============================================================================
@@ -204,10 +254,12 @@ func setupEnhancedRouter(container *EnhancedApplicationContainer) *gin.Engine {
 		})
 
 		apiV1.GET("/health/ready", func(c *gin.Context) {
-			container.ResponseHandler.Success(c, map[string]interface{}{
-				"ready":     true,
-				"timestamp": time.Now(),
-			})
+			report := container.Readiness.Check(c.Request.Context())
+			if !report.Ready {
+				container.ResponseHandler.ServiceUnavailable(c, "Service is not ready", report)
+				return
+			}
+			container.ResponseHandler.Success(c, report)
 		})
 
 		apiV1.GET("/health/live", func(c *gin.Context) {
@@ -308,66 +360,6 @@ func setupEnhancedRouter(container *EnhancedApplicationContainer) *gin.Engine {
 				},
 			})
 		})
-
-		// Swagger UI endpoint
-		apiV1.GET("/docs", func(c *gin.Context) {
-			c.HTML(http.StatusOK, "swagger.html", gin.H{
-				"title": "Form Service API Documentation",
-				"spec":  "/api/v1/swagger.json",
-			})
-		})
-
-		// Swagger JSON spec endpoint
-		apiV1.GET("/swagger.json", func(c *gin.Context) {
-			container.ResponseHandler.Success(c, map[string]interface{}{
-				"openapi": "3.0.0",
-				"info": map[string]interface{}{
-					"title":       "Form Service API",
-					"description": "Comprehensive form management service with microservices best practices",
-					"version":     "1.0.0",
-					"contact": map[string]string{
-						"name":  "Form Service Team",
-						"email": "form-service@example.com",
-					},
-				},
-				"servers": []map[string]string{
-					{"url": "/api/v1"},
-				},
-				"paths": map[string]interface{}{
-					"/forms": map[string]interface{}{
-						"get": map[string]interface{}{
-							"summary": "List forms",
-							"tags":    []string{"Forms"},
-							"responses": map[string]interface{}{
-								"200": map[string]interface{}{
-									"description": "Successful response",
-								},
-							},
-						},
-						"post": map[string]interface{}{
-							"summary": "Create form",
-							"tags":    []string{"Forms"},
-							"responses": map[string]interface{}{
-								"201": map[string]interface{}{
-									"description": "Form created successfully",
-								},
-							},
-						},
-					},
-					"/health": map[string]interface{}{
-						"get": map[string]interface{}{
-							"summary": "Health check",
-							"tags":    []string{"Health"},
-							"responses": map[string]interface{}{
-								"200": map[string]interface{}{
-									"description": "Service is healthy",
-								},
-							},
-						},
-					},
-				},
-			})
-		})
 	}
 
 	// =============================================================================
@@ -389,30 +381,17 @@ func setupEnhancedRouter(container *EnhancedApplicationContainer) *gin.Engine {
 
 // startServerWithGracefulShutdown starts the server with graceful shutdown support
 func startServerWithGracefulShutdown(server *http.Server, container *EnhancedApplicationContainer) {
+	logger := container.Observability.Logger()
+
 	// Start server in a goroutine
 	go func() {
-		log.Printf("🚀 Enhanced Form Service starting on port %s", container.Config.Port)
-		log.Printf("📊 Environment: %s", container.Config.Environment)
-		log.Printf("🏗️  Architecture: Clean Architecture with Microservices Best Practices")
-		log.Printf("📋 Microservices Features Implemented:")
-		log.Printf("   ✅ API Versioning (/api/v1/)")
-		log.Printf("   ✅ Comprehensive DTOs")
-		log.Printf("   ✅ Input Validation with Security")
-		log.Printf("   ✅ Swagger Documentation")
-		log.Printf("   ✅ Rate Limiting")
-		log.Printf("   ✅ Correlation ID Tracing")
-		log.Printf("   ✅ Security Headers")
-		log.Printf("   ✅ Health Monitoring")
-		log.Printf("   ✅ Structured Error Handling")
-		log.Printf("   ✅ Graceful Shutdown")
-		log.Printf("🔗 Endpoints:")
-		log.Printf("   📖 API Documentation: http://localhost:%s/api/v1/docs", container.Config.Port)
-		log.Printf("   🏥 Health Check: http://localhost:%s/api/v1/health", container.Config.Port)
-		log.Printf("   📊 Service Info: http://localhost:%s/api/v1/info", container.Config.Port)
-		log.Printf("   📈 Microservices Status: http://localhost:%s/", container.Config.Port)
+		logger.Info("Enhanced Form Service starting",
+			zap.String("port", container.Config.Port),
+			zap.String("docs", fmt.Sprintf("http://localhost:%s/swagger/index.html", container.Config.Port)),
+			zap.String("metrics", fmt.Sprintf("http://localhost:%s/metrics", container.Config.Port)))
 
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v", err)
+			logger.Fatal("Failed to start server", zap.Error(err))
 		}
 	}()
 
@@ -421,15 +400,21 @@ func startServerWithGracefulShutdown(server *http.Server, container *EnhancedApp
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("🛑 Shutting down Enhanced Form Service...")
+	logger.Info("Shutting down Enhanced Form Service")
 
 	// Give outstanding requests time to complete (graceful shutdown)
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		logger.Fatal("Server forced to shutdown", zap.Error(err))
+	}
+
+	// Export the last spans and error reports
+	if err := container.Observability.Shutdown(ctx); err != nil {
+		logger.Warn("Failed to flush observability", zap.Error(err))
 	}
 
-	log.Println("✅ Enhanced Form Service exited gracefully")
+	logger.Info("Enhanced Form Service exited gracefully")
+	logger.Sync()
 }
@@ -91,14 +91,17 @@ func NewApplicationContainer() (*ApplicationContainer, error) {
 	startTime := time.Now()
 
 	// Load configuration
-	cfg := config.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
 
 	// Initialize logger
 	logger := logrus.New()
 	logger.SetLevel(logrus.InfoLevel)
 
 	// Initialize database connection
-	db, err := database.Connect(cfg.DatabaseURL)
+	db, err := database.Connect(cfg.DatabaseURL, cfg.Database)
 	if err != nil {
 		log.Printf("Warning: Database connection failed: %v", err)
 		log.Printf("Continuing without database for demonstration purposes")
@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
@@ -9,23 +11,111 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	// Report schedules run in IANA time zones, which slim images lack
+	_ "time/tzdata"
 
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/analytics"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/challenge"
 	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/config"
 	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/database"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/funnel"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/health"
 	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
 
 	// Repository and Service layers (following Clean Architecture)
 	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/handlers"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/receipt"
 	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/responses"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/routetable"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/scanner"
 	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/service"
+	"github.com/Mir00r/X-Form-Backend/shared/errreport"
+	"github.com/Mir00r/X-Form-Backend/shared/flags"
+	"github.com/Mir00r/X-Form-Backend/shared/startup"
+	"github.com/Mir00r/X-Form-Backend/shared/storage"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+	"gorm.io/gorm"
+
+	_ "github.com/Mir00r/X-Form-Backend/services/form-service/docs"
 )
 
 // ApplicationContainer demonstrates Clean Architecture principles
 // Implements Dependency Injection Container pattern
 type ApplicationContainer struct {
-	Config      *config.Config
-	FormHandler *handlers.FormHandler
+	Config        *config.Config
+	FormHandler   *handlers.FormHandler
+	UploadHandler *handlers.UploadHandler
+	FileHandler   *handlers.FileHandler
+	DraftHandler  *handlers.DraftHandler
+	// ReceiptHandler renders the PDF receipts of responses for the response
+	// service
+	ReceiptHandler *handlers.ReceiptHandler
+	// NotificationHandler serves the settings read by the notification
+	// worker of the event bus, test notifications and the Slack and Teams
+	// channels of forms
+	NotificationHandler *handlers.NotificationHandler
+	ReportHandler       *handlers.ReportHandler
+	CollaboratorHandler *handlers.CollaboratorHandler
+	OrganizationHandler *handlers.OrganizationHandler
+	LibraryHandler      *handlers.LibraryHandler
+	// TransferHandler serves the transfers of forms between owners and
+	// organizations
+	TransferHandler *handlers.TransferHandler
+	// ResponseDeletionHandler serves the bulk deletions of responses, run
+	// by ResponseDeletionService
+	ResponseDeletionHandler *handlers.ResponseDeletionHandler
+	ResponseDeletionService service.ResponseDeletionService
+	PreviewHandler          *handlers.PreviewHandler
+	EmbedHandler            *handlers.EmbedHandler
+	// PrivacyHandler serves the data subject requests of PrivacyService,
+	// which deletes the archives of users once their links expired
+	PrivacyHandler *handlers.PrivacyHandler
+	PrivacyService service.PrivacyService
+	// ActivityHandler serves the activity feed of forms
+	ActivityHandler *handlers.ActivityHandler
+	// ResultsHandler serves the public results of published forms
+	ResultsHandler *handlers.ResultsHandler
+	// AnalyticsHandler serves the drill-down queries of form owners
+	AnalyticsHandler *handlers.AnalyticsHandler
+	// FunnelHandler serves the funnel beacons of public forms and their
+	// daily funnels
+	FunnelHandler *handlers.FunnelHandler
+	// ProtectionHandler serves the protection status of forms and their
+	// throttling by the anomaly detector of the event bus
+	ProtectionHandler *handlers.ProtectionHandler
+	// CleanupHandler serves the form cleanups of admins relayed by the gateway
+	CleanupHandler *handlers.CleanupHandler
+	// CacheHandler serves the cache clears of admins relayed by the gateway
+	CacheHandler   *handlers.CacheHandler
+	UploadService  service.UploadService
+	FileService    service.FileService
+	DraftService   service.DraftService
+	ReportService  service.ReportService
+	CleanupService service.CleanupService
+	Storage        storage.Backend
+	Readiness      *health.Checker
+	// ExportHandler serves the response exports of forms, written by
+	// ExportService
+	ExportHandler *handlers.ExportHandler
+	ExportService service.ExportService
+	// UsageHandler serves the usage of organizations and admits responses
+	// against their quotas; UsageMeter flushes the usage counted and
+	// UsageService reconciles it
+	UsageHandler *handlers.UsageHandler
+	UsageMeter   *service.UsageMeter
+	UsageService service.UsageService
+	// FeatureFlags evaluates the feature flags defined in the configuration
+	// and changed through the gateway
+	FeatureFlags *flags.Client
+	// ErrorReporter reports the panics and 5xx responses of requests
+	ErrorReporter errreport.Reporter
 }
 
 // NewApplicationContainer creates application dependencies following SOLID principles
@@ -33,41 +123,340 @@ type ApplicationContainer struct {
 // Dependency Inversion: High-level modules don't depend on low-level modules
 func NewApplicationContainer() (*ApplicationContainer, error) {
 	// Load configuration (Infrastructure concern)
-	cfg := config.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	errorReporter, err := errreport.New(cfg.ErrorReporting)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create error reporter: %w", err)
+	}
 
-	// Initialize database connection (Infrastructure layer)
-	db, err := database.Connect(cfg.DatabaseURL)
+	// Initialize database and Redis connections (Infrastructure layer). On
+	// a cold start of the cluster they may come up after the service, so
+	// they are retried until STARTUP_TIMEOUT.
+	redisClient, err := database.OpenRedis(cfg.RedisURL)
+	if err != nil {
+		return nil, err
+	}
+	var db *gorm.DB
+	gate := startup.Gate{Timeout: cfg.StartupTimeout, Observe: logStartupAttempt}
+	err = gate.WaitFor(context.Background(),
+		startup.Check{Name: "postgres", Run: func(ctx context.Context) error {
+			db, err = database.Connect(cfg.DatabaseURL, cfg.Database)
+			return err
+		}},
+		startup.Check{Name: "redis", Run: func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		}},
+	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
+		return nil, err
+	}
+	if err := database.RegisterMetrics(prometheus.DefaultRegisterer, db); err != nil {
+		return nil, fmt.Errorf("failed to register database metrics: %w", err)
 	}
 
-	// Auto-migrate database schema
-	if err := database.Migrate(db); err != nil {
-		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	// Production migrates with `form-service migrate up` before rolling out
+	// a release, so the server only checks that the schema is not behind
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	if cfg.AutoMigrate {
+		if err := migrator.Up(context.Background()); err != nil {
+			return nil, fmt.Errorf("failed to migrate database: %w", err)
+		}
+	} else if err := migrator.CheckVersion(context.Background()); err != nil {
+		return nil, err
 	}
 
 	// Initialize repositories (Data Access Layer)
 	// Repository Pattern: Abstracts data persistence concerns
 	formRepo := repository.NewFormRepository(db)
 	questionRepo := repository.NewQuestionRepository(db)
+	collaboratorRepo := repository.NewCollaboratorRepository(db)
+	orgRepo := repository.NewOrganizationRepository(db)
 
 	// Initialize services (Business Logic Layer)
 	// Service Layer Pattern: Encapsulates business rules and use cases
-	formService := service.NewFormService(formRepo, questionRepo)
+	publisher := events.NewPublisher(cfg.EventBusURL)
+	auditor := events.NewAuditor(cfg.EventBusURL, cfg.AuditTopic, cfg.AuditBufferSize, prometheus.DefaultRegisterer)
+
+	// Changes to forms are recorded to their activity feed and pushed to the
+	// builders open on them by the collaboration service
+	activityLog := service.NewActivityLog(cfg.ActivityMaxPerForm, events.NewRedisActivityPublisher(redisClient))
+
+	// Feature flags are shared with the gateway through Redis
+	definedFlags, err := flags.Parse(cfg.FeatureFlags)
+	if err != nil {
+		return nil, err
+	}
+	featureFlags := flags.NewClient(flags.NewRedisStore(redisClient, flags.DefaultRedisKey), definedFlags)
+	if err := featureFlags.Refresh(context.Background()); err != nil {
+		log.Printf("Feature flags changed through the gateway are not loaded yet: %v", err)
+	}
+	flags.SetDefault(featureFlags)
+
+	// Usage is counted in Redis as it happens and flushed to PostgreSQL;
+	// the quotas of the plans are enforced against it
+	plans, err := cfg.Plans()
+	if err != nil {
+		return nil, err
+	}
+	usageRepo := repository.NewUsageRepository(db)
+	usageMeter := service.NewUsageMeter(repository.NewRedisUsageCounter(redisClient), usageRepo, formRepo, orgRepo, plans, cfg.QuotasEnabled)
+
+	// Published definitions are cached in Redis for the public fetches,
+	// unless FORM_DEFINITION_CACHE_ENABLED turns the cache off
+	var definitions *service.DefinitionCache
+	if cfg.FormDefinitionCacheEnabled {
+		definitions = service.NewDefinitionCache(repository.NewRedisDefinitionCache(redisClient), cfg.FormDefinitionCacheTTL,
+			service.NewDefinitionCacheMetrics(prometheus.DefaultRegisterer))
+	}
+	formService := service.NewFormService(formRepo, questionRepo, collaboratorRepo, orgRepo, publisher, auditor, challenge.NewIssuer(cfg.Challenge), activityLog, usageMeter, definitions)
+
+	// Object storage for uploads, exports, receipts and archives: local disk
+	// in dev, S3, GCS or Azure otherwise. Organizations keeping their
+	// objects apart have them stored in their own buckets; the URLs their
+	// stores can't sign are served by the storage proxy.
+	backend, err := storage.New(cfg.Storage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	buckets, err := cfg.OrganizationBuckets()
+	if err != nil {
+		return nil, err
+	}
+	var storageRouter *storage.Router
+	if len(buckets) > 0 {
+		secrets, err := storage.NewSecretResolver(cfg.StorageSecrets)
+		if err != nil {
+			return nil, err
+		}
+		tenants := service.NewStorageTenants(repository.NewFormOrganizations(db))
+		storageRouter = storage.NewRouter(backend, buckets, tenants.TenantOf, secrets, cfg.StorageCredentialTTL)
+		backend = storageRouter
+	}
+	store, err := storage.NewProxy(backend, cfg.Storage.PublicBaseURL, cfg.Storage.SigningSecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	fileScanner, err := scanner.New(cfg.Scanner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize scanner: %w", err)
+	}
+	fileUploadRepo := repository.NewFileUploadRepository(db)
+	fileService := service.NewFileService(fileUploadRepo, formRepo, collaboratorRepo, orgRepo, store, fileScanner, publisher, cfg.FileScanStrictMode)
+	uploadService := service.NewUploadService(fileUploadRepo, questionRepo, store, publisher, fileService, usageMeter)
+
+	// Response drafts live in Redis, written through to PostgreSQL for logged-in users
+	draftCache := repository.NewRedisDraftCache(redisClient)
+	draftRepo := repository.NewDraftRepository(db)
+	draftService := service.NewDraftService(formRepo, draftCache, draftRepo, cfg.DraftTTL)
+
+	// Receipts of responses are rendered once, then served from storage
+	receiptFonts, err := receipt.LoadFonts(cfg.ReceiptFontPath, cfg.ReceiptBoldFontPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load receipt fonts: %w", err)
+	}
+	receiptService := service.NewReceiptService(formRepo, questionRepo, collaboratorRepo, orgRepo, fileUploadRepo, store, receipt.NewRenderer(receiptFonts))
+
+	// Scheduled reports are aggregated by the analytics service; one replica
+	// at a time runs the scheduler
+	analyticsClient := analytics.NewClient(cfg.AnalyticsServiceURL, cfg.AnalyticsServiceToken)
+	reportService := service.NewReportService(formRepo, repository.NewReportRepository(db),
+		analyticsClient, store, publisher,
+		repository.NewRedisLock(redisClient, "form-service:report-scheduler"))
+
+	// Admin cleanups archive abandoned forms; forms are selected by their
+	// responses only when the analytics service counts them
+	var responseCounter analytics.Aggregator
+	if cfg.AnalyticsServiceURL != "" {
+		responseCounter = analyticsClient
+	}
+	cleanupService := service.NewCleanupService(formRepo, questionRepo, repository.NewCleanupJobRepository(db),
+		responseCounter, store, publisher, auditor, definitions)
+
+	// Drill-down queries and response exports read the response projection
+	// of the event store database directly
+	var querier analytics.Querier
+	var timings analytics.TimingReader
+	var responseReader analytics.ResponseReader
+	var responseUsage analytics.ResponseCounter
+	var funnels analytics.FunnelReader
+	if cfg.AnalyticsDatabaseURL != "" {
+		// The replicas are those of DATABASE_URL
+		analyticsConfig := cfg.Database
+		analyticsConfig.ReplicaURLs = nil
+		analyticsDB, err := database.Connect(cfg.AnalyticsDatabaseURL, analyticsConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to analytics database: %w", err)
+		}
+		sqlDB, err := analyticsDB.DB()
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to analytics database: %w", err)
+		}
+		projection := analytics.NewProjection(sqlDB)
+		querier, timings, responseReader, responseUsage, funnels = projection, projection, projection, projection, projection
+	}
+	exportService := service.NewExportService(formRepo, questionRepo, collaboratorRepo, orgRepo, repository.NewExportJobRepository(db),
+		responseReader, fileUploadRepo, store, service.ExportConfig{
+			MaxArchiveBytes:        cfg.ExportMaxArchiveBytes,
+			LinkTTL:                cfg.ExportLinkTTL,
+			SigningSecret:          cfg.Storage.SigningSecret,
+			SingleUseLinks:         cfg.ExportLinkSingleUse,
+			MaxConcurrentJobs:      cfg.ExportMaxConcurrentJobs,
+			MaxJobsPerOrganization: cfg.ExportMaxJobsPerOrganization,
+		}, usageMeter, service.NewExportMetrics(prometheus.DefaultRegisterer), repository.NewRedisLinkRedemptions(redisClient))
+	// Without the projection, reconciliation keeps the metered response
+	// counts
+	usageService := service.NewUsageService(usageMeter, formRepo, orgRepo, responseUsage,
+		repository.NewRedisLock(redisClient, "form-service:usage-reconciler"), service.ExportLimits{
+			MaxConcurrentJobs:      cfg.ExportMaxConcurrentJobs,
+			MaxJobsPerOrganization: cfg.ExportMaxJobsPerOrganization,
+		})
+
+	// Bulk deletions select and delete responses through the response
+	// service, releasing them from the quotas
+	responseDeletionService := service.NewResponseDeletionService(formRepo, collaboratorRepo, orgRepo, repository.NewResponseDeletionRepository(db),
+		responses.NewClient(cfg.ResponseServiceURL, cfg.ResponseServiceAPIKey), publisher, auditor, usageMeter, service.ResponseDeletionConfig{
+			Secret:     cfg.BulkDeleteTokenSecret,
+			TokenTTL:   cfg.BulkDeleteTokenTTL,
+			MaxPercent: cfg.BulkDeleteMaxPercent,
+			BatchSize:  cfg.BulkDeleteBatchSize,
+		})
+
+	// Data subject requests erase or export everything tied to a user
+	privacyService := service.NewPrivacyService(repository.NewPrivacyRepository(db), draftCache, store,
+		models.ErasurePolicy(cfg.PrivacyErasurePolicy), cfg.PrivacyExportLinkTTL)
+
+	// Readiness reflects the dependencies requests need
+	readiness := health.NewChecker(cfg.ReadinessTimeout)
+	readiness.AddDetailed("database", health.Database(db), health.DatabasePool(db))
+	readiness.Add("redis", health.Redis(redisClient))
+	if cfg.ReadinessCheckMigrations {
+		readiness.Add("migrations", health.Migrations(migrator.Pending))
+	}
+	if storageRouter != nil {
+		readiness.AddDetailed("storage", health.Storage(store), health.StorageBuckets(storageRouter))
+	} else {
+		readiness.Add("storage", health.Storage(store))
+	}
 
 	// Initialize handlers (Presentation Layer)
 	// Controller Pattern: Handles HTTP requests and responses
-	formHandler := handlers.NewFormHandler(formService)
+	viewTokens := funnel.NewTokens(cfg.FunnelTokenSecret)
+	formHandler := handlers.NewFormHandler(formService, viewTokens, cfg.CSVRowLimit)
+	uploadHandler := handlers.NewUploadHandler(uploadService)
+	fileHandler := handlers.NewFileHandler(fileService)
+	draftHandler := handlers.NewDraftHandler(draftService)
+	notificationHandler := handlers.NewNotificationHandler(service.NewNotificationService(formRepo, collaboratorRepo, orgRepo, repository.NewNotificationChannelRepository(db), publisher, cfg.NotificationChannelSecret))
+	reportHandler := handlers.NewReportHandler(reportService)
+	collaboratorHandler := handlers.NewCollaboratorHandler(service.NewCollaboratorService(formRepo, collaboratorRepo, orgRepo, auditor))
+	organizationHandler := handlers.NewOrganizationHandler(service.NewOrganizationService(orgRepo, auditor))
+	libraryHandler := handlers.NewLibraryHandler(service.NewLibraryService(repository.NewLibraryRepository(db), formRepo, questionRepo, collaboratorRepo, orgRepo, auditor, definitions))
+	transferHandler := handlers.NewTransferHandler(service.NewTransferService(repository.NewTransferRepository(db), formRepo, collaboratorRepo, orgRepo, publisher, auditor, usageMeter, definitions))
+	previewHandler := handlers.NewPreviewHandler(service.NewPreviewService(repository.NewPreviewTokenRepository(db), formRepo, questionRepo, collaboratorRepo, orgRepo, auditor, cfg.Preview))
+	resultsService := service.NewResultsService(formRepo, questionRepo, analyticsClient, service.ResultsConfig{
+		CacheTTL:     cfg.PublicResultsCacheTTL,
+		MinResponses: cfg.PublicResultsMinResponses,
+	})
+	resultsHandler := handlers.NewResultsHandler(resultsService, cfg.PublicResultsCacheTTL)
+	analyticsHandler := handlers.NewAnalyticsHandler(service.NewAnalyticsQueryService(formRepo, questionRepo, collaboratorRepo, orgRepo, querier, cfg.AnalyticsQueryMaxDistinctValues,
+		timings, draftRepo, service.TimingConfig{
+			SampleLimit:  cfg.AnalyticsTimingSampleLimit,
+			AbandonAfter: cfg.DraftAbandonAfter,
+		}), cfg.CSVRowLimit)
+	activityHandler := handlers.NewActivityHandler(service.NewActivityService(repository.NewActivityRepository(db), formRepo, collaboratorRepo, orgRepo))
+	funnelHandler := handlers.NewFunnelHandler(service.NewFunnelService(formRepo, collaboratorRepo, orgRepo, viewTokens,
+		repository.NewRedisFunnelBeacons(redisClient), publisher, funnels, service.FunnelConfig{
+			RateLimit:  cfg.FunnelBeaconRateLimit,
+			RateWindow: cfg.FunnelBeaconRateWindow,
+		}))
 
 	return &ApplicationContainer{
-		Config:      cfg,
-		FormHandler: formHandler,
+		Config:                  cfg,
+		FormHandler:             formHandler,
+		UploadHandler:           uploadHandler,
+		FileHandler:             fileHandler,
+		DraftHandler:            draftHandler,
+		ReceiptHandler:          handlers.NewReceiptHandler(receiptService),
+		NotificationHandler:     notificationHandler,
+		ReportHandler:           reportHandler,
+		CollaboratorHandler:     collaboratorHandler,
+		OrganizationHandler:     organizationHandler,
+		LibraryHandler:          libraryHandler,
+		TransferHandler:         transferHandler,
+		ResponseDeletionHandler: handlers.NewResponseDeletionHandler(responseDeletionService),
+		ResponseDeletionService: responseDeletionService,
+		PreviewHandler:          previewHandler,
+		ActivityHandler:         activityHandler,
+		EmbedHandler:            handlers.NewEmbedHandler(formService, viewTokens, cfg.EmbedAPIBaseURL),
+		ResultsHandler:          resultsHandler,
+		AnalyticsHandler:        analyticsHandler,
+		FunnelHandler:           funnelHandler,
+		PrivacyHandler:          handlers.NewPrivacyHandler(privacyService),
+		PrivacyService:          privacyService,
+		ProtectionHandler:       handlers.NewProtectionHandler(formService),
+		CleanupHandler:          handlers.NewCleanupHandler(cleanupService),
+		CacheHandler:            handlers.NewCacheHandler(map[string]service.CachePurger{"public_results": resultsService}),
+		UploadService:           uploadService,
+		FileService:             fileService,
+		DraftService:            draftService,
+		ReportService:           reportService,
+		CleanupService:          cleanupService,
+		Storage:                 store,
+		Readiness:               readiness,
+		ExportHandler:           handlers.NewExportHandler(exportService, cfg.ExportDownloadStreaming),
+		ExportService:           exportService,
+		UsageHandler:            handlers.NewUsageHandler(usageService),
+		UsageMeter:              usageMeter,
+		UsageService:            usageService,
+		FeatureFlags:            featureFlags,
+		ErrorReporter:           errorReporter,
 	}, nil
 }
 
+//go:generate go run . -routes ../../docs/routes.json
+//go:generate swag init --dir ./,../../internal/handlers,../../internal/health --generalInfo main.go --output ../../docs --parseDependency --parseInternal
+
+// @title                      Form Service API
+// @version                    1.0.0
+// @description                Form management, uploads and response drafts. Errors are returned as {"error": "..."}.
+// @BasePath                   /
+// @securityDefinitions.apikey BearerAuth
+// @in                         header
+// @name                       Authorization
+// @description                JWT issued by the auth service, sent as "Bearer <token>"
+// @securityDefinitions.apikey ServiceToken
+// @in                         header
+// @name                       Authorization
+// @description                INTERNAL_SERVICE_TOKEN shared with the calling services, sent as "Bearer <token>"
 func main() {
+	// form-service migrate manages the schema; see migrate.go
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+
+	routesFile := flag.String("routes", "", "write the route manifest to this file and exit")
+	flag.Parse()
+	if *routesFile != "" {
+		if err := writeRouteManifest(*routesFile); err != nil {
+			log.Fatalf("Failed to write route manifest: %v", err)
+		}
+		return
+	}
+
 	// Initialize application container with dependency injection
 	container, err := NewApplicationContainer()
+	if errors.Is(err, startup.ErrUnavailable) {
+		log.Printf("Dependencies unavailable at startup: %v", err)
+		os.Exit(startup.ExitUnavailable)
+	}
 	if err != nil {
 		log.Fatalf("Failed to initialize application container: %v", err)
 	}
@@ -77,14 +466,48 @@ func main() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	// Background workers: orphaned upload cleanup, antivirus scanning, expired
+	// draft cleanup, scheduled reports, admin form cleanups, response
+	// exports, bulk response deletions, expired privacy exports, the flushing
+	// and reconciliation of usage, and the refreshing of feature flags
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	go container.UploadService.RunOrphanCleanup(workerCtx, time.Hour)
+	go container.FileService.Run(workerCtx)
+	go container.DraftService.RunDraftCleanup(workerCtx, time.Hour)
+	if container.Config.AnalyticsServiceURL != "" {
+		go container.ReportService.RunScheduler(workerCtx, container.Config.ReportSchedulerInterval)
+	}
+	go container.CleanupService.RunJobs(workerCtx, 30*time.Second)
+	go container.ExportService.RunJobs(workerCtx, 10*time.Second)
+	go container.ResponseDeletionService.RunJobs(workerCtx, 10*time.Second)
+	go container.PrivacyService.RunExportExpiry(workerCtx, time.Hour)
+	go container.UsageMeter.RunFlusher(workerCtx, container.Config.UsageFlushInterval)
+	go container.UsageService.RunReconciler(workerCtx, container.Config.UsageReconcileInterval)
+	go container.FeatureFlags.Run(workerCtx, container.Config.FeatureFlagsRefreshInterval, func(err error) {
+		log.Printf("Failed to refresh feature flags: %v", err)
+	})
+
 	// Setup and start HTTP server with graceful shutdown
 	server := setupHTTPServer(container)
 	startServerGracefully(server, container.Config.Port)
+	container.ErrorReporter.Flush(5 * time.Second)
+}
+
+// logStartupAttempt logs the attempts to connect to the dependencies at
+// startup which failed or followed a failure
+func logStartupAttempt(attempt startup.Attempt) {
+	switch {
+	case attempt.Err != nil:
+		log.Printf("Waiting for %s, retrying in %s: %v", attempt.Check, attempt.Wait, attempt.Err)
+	case attempt.Number > 1:
+		log.Printf("Connected to %s after %d attempts", attempt.Check, attempt.Number)
+	}
 }
 
 // setupHTTPServer configures the HTTP server with timeouts
 func setupHTTPServer(container *ApplicationContainer) *http.Server {
-	router := setupRouter(container)
+	router, _ := setupRouter(container)
 
 	return &http.Server{
 		Addr:         fmt.Sprintf(":%s", container.Config.Port),
@@ -95,33 +518,127 @@ func setupHTTPServer(container *ApplicationContainer) *http.Server {
 	}
 }
 
-// setupRouter configures routes and middleware following RESTful principles
-func setupRouter(container *ApplicationContainer) *gin.Engine {
+// writeRouteManifest writes the route table read by the API Gateway contract
+// tests. Handlers are never called, so no dependencies are connected.
+func writeRouteManifest(name string) error {
+	gin.SetMode(gin.ReleaseMode)
+	_, routes := setupRouter(&ApplicationContainer{
+		Config:                  &config.Config{},
+		FormHandler:             handlers.NewFormHandler(nil, nil, 0),
+		UploadHandler:           handlers.NewUploadHandler(nil),
+		FileHandler:             handlers.NewFileHandler(nil),
+		DraftHandler:            handlers.NewDraftHandler(nil),
+		ReceiptHandler:          handlers.NewReceiptHandler(nil),
+		NotificationHandler:     handlers.NewNotificationHandler(nil),
+		ReportHandler:           handlers.NewReportHandler(nil),
+		CollaboratorHandler:     handlers.NewCollaboratorHandler(nil),
+		OrganizationHandler:     handlers.NewOrganizationHandler(nil),
+		LibraryHandler:          handlers.NewLibraryHandler(nil),
+		TransferHandler:         handlers.NewTransferHandler(nil),
+		ResponseDeletionHandler: handlers.NewResponseDeletionHandler(nil),
+		PreviewHandler:          handlers.NewPreviewHandler(nil),
+		ActivityHandler:         handlers.NewActivityHandler(nil),
+		EmbedHandler:            handlers.NewEmbedHandler(nil, nil, ""),
+		ResultsHandler:          handlers.NewResultsHandler(nil, 0),
+		AnalyticsHandler:        handlers.NewAnalyticsHandler(nil, 0),
+		FunnelHandler:           handlers.NewFunnelHandler(nil),
+		PrivacyHandler:          handlers.NewPrivacyHandler(nil),
+		CleanupHandler:          handlers.NewCleanupHandler(nil),
+		CacheHandler:            handlers.NewCacheHandler(nil),
+		Readiness:               health.NewChecker(0),
+		ExportHandler:           handlers.NewExportHandler(nil, false),
+		UsageHandler:            handlers.NewUsageHandler(nil),
+	})
+	return routes.WriteFile(name)
+}
+
+// setupRouter configures routes and middleware following RESTful principles.
+// Every route is recorded in the returned table.
+func setupRouter(container *ApplicationContainer) (*gin.Engine, *routetable.Table) {
 	cfg := container.Config
 	formHandler := container.FormHandler
+	uploadHandler := container.UploadHandler
+	fileHandler := container.FileHandler
+	draftHandler := container.DraftHandler
+	receiptHandler := container.ReceiptHandler
+	notificationHandler := container.NotificationHandler
+	reportHandler := container.ReportHandler
+	collaboratorHandler := container.CollaboratorHandler
+	organizationHandler := container.OrganizationHandler
+	libraryHandler := container.LibraryHandler
+	transferHandler := container.TransferHandler
+	responseDeletionHandler := container.ResponseDeletionHandler
+	previewHandler := container.PreviewHandler
+	activityHandler := container.ActivityHandler
+	embedHandler := container.EmbedHandler
+	resultsHandler := container.ResultsHandler
+	analyticsHandler := container.AnalyticsHandler
+	funnelHandler := container.FunnelHandler
+	privacyHandler := container.PrivacyHandler
+	protectionHandler := container.ProtectionHandler
+	cleanupHandler := container.CleanupHandler
+	exportHandler := container.ExportHandler
+	usageHandler := container.UsageHandler
+	cacheHandler := container.CacheHandler
+
+	// The route manifest and the tests build the router without a reporter
+	errorReporter := container.ErrorReporter
+	if errorReporter == nil {
+		errorReporter = errreport.NewNoop()
+	}
 
 	router := gin.New()
 
 	// Apply middleware (Cross-cutting concerns)
 	// Each middleware follows Single Responsibility Principle
 	router.Use(gin.Logger())
-	router.Use(gin.Recovery())
+	router.Use(middleware.ErrorReporting(errorReporter))
+	router.Use(handlers.CorrelationIDMiddleware())
+	router.Use(middleware.Organization())
+	router.Use(middleware.DatabaseSession())
 	router.Use(middleware.CORS())
 	router.Use(middleware.Security())
 
+	routes := routetable.New("form-service")
+	routes.Middleware(middleware.AuthRequired(cfg.JWTSecret), routetable.AuthRequired)
+	routes.Middleware(middleware.OptionalAuth(cfg.JWTSecret), routetable.AuthOptional)
+	routes.Middleware(middleware.ServiceAuth(cfg.InternalServiceToken), routetable.AuthService)
+	root := routes.Wrap(&router.RouterGroup)
+
 	// Health check endpoint for monitoring and observability
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"status":       "healthy",
-			"service":      "form-service",
-			"timestamp":    time.Now().UTC().Format(time.RFC3339),
-			"version":      "1.0.0",
-			"architecture": "Clean Architecture with SOLID Principles",
-		})
-	})
+	root.GET("/health", healthCheck)
+
+	// Kubernetes probes: liveness checks nothing, readiness every dependency
+	root.GET("/health/live", health.LiveHandler())
+	root.GET("/health/ready", container.Readiness.ReadyHandler())
+
+	// Internal endpoints for other services, not routed by the gateway
+	// The ones changing or exporting data admit only the services holding
+	// the internal service token
+	serviceAuth := middleware.ServiceAuth(cfg.InternalServiceToken)
+	root.GET("/internal/stats", formHandler.InternalStats)
+	root.POST("/internal/forms/:id/responses/validate", formHandler.CheckResponse)
+	root.POST("/internal/forms/:id/responses/test/authorize", formHandler.AuthorizeTestSubmission)
+	root.POST("/internal/forms/:id/responses/draft/consume", serviceAuth, draftHandler.ConsumeDraft)
+	root.POST("/internal/forms/:id/responses/receipt", receiptHandler.RenderReceipt)
+	root.GET("/internal/forms/:id/notifications", notificationHandler.GetNotificationTarget)
+	root.GET("/internal/forms/:id/throttling", protectionHandler.GetThrottleState)
+	root.PUT("/internal/forms/:id/throttling", protectionHandler.SetThrottled)
+	root.POST("/internal/privacy/erasure", serviceAuth, privacyHandler.EraseUser)
+	root.POST("/internal/privacy/export", serviceAuth, privacyHandler.ExportUser)
+	root.POST("/internal/admin/forms/cleanup", serviceAuth, cleanupHandler.StartCleanup)
+	root.GET("/internal/admin/forms/cleanup/:id", serviceAuth, cleanupHandler.GetCleanupJob)
+	root.DELETE("/internal/admin/forms/cleanup/:id", serviceAuth, cleanupHandler.CancelCleanupJob)
+	root.GET("/internal/admin/usage/:orgId", usageHandler.GetUsage)
+	root.GET("/internal/users/:userId/limits", usageHandler.GetLimits)
+	root.POST("/internal/cache/purge", serviceAuth, cacheHandler.PurgeCaches)
+	root.POST("/internal/forms/:id/usage/responses", usageHandler.AdmitResponse)
+	// Uploads are attached to responses only by the response service, as it
+	// creates them
+	root.POST("/internal/forms/:id/questions/:qid/files/verify", serviceAuth, uploadHandler.VerifyFileTokens)
 
 	// API versioning for backward compatibility
-	api := router.Group("/api/v1")
+	api := root.Group("/api/v1")
 	{
 		// Form resource routes following REST conventions
 		forms := api.Group("/forms")
@@ -129,14 +646,171 @@ func setupRouter(container *ApplicationContainer) *gin.Engine {
 			// CRUD operations with proper HTTP methods
 			// Each route follows Interface Segregation Principle
 			forms.POST("", middleware.AuthRequired(cfg.JWTSecret), formHandler.CreateForm)
+			forms.GET("", middleware.AuthRequired(cfg.JWTSecret), formHandler.GetUserForms)
+			forms.GET("/changes", middleware.AuthRequired(cfg.JWTSecret), formHandler.GetFormChanges)
 			forms.GET("/:id", middleware.OptionalAuth(cfg.JWTSecret), formHandler.GetForm)
 			forms.PUT("/:id", middleware.AuthRequired(cfg.JWTSecret), formHandler.UpdateForm)
+			forms.POST("/:id/merge", middleware.AuthRequired(cfg.JWTSecret), formHandler.MergeForm)
 			forms.DELETE("/:id", middleware.AuthRequired(cfg.JWTSecret), formHandler.DeleteForm)
 			forms.POST("/:id/publish", middleware.AuthRequired(cfg.JWTSecret), formHandler.PublishForm)
+			forms.PUT("/:id/translations/:locale", middleware.AuthRequired(cfg.JWTSecret), formHandler.UpsertTranslation)
+			forms.POST("/:id/notifications/test", middleware.AuthRequired(cfg.JWTSecret), notificationHandler.SendTestNotification)
+			forms.GET("/:id/notifications/channels", middleware.AuthRequired(cfg.JWTSecret), notificationHandler.ListChannels)
+			forms.POST("/:id/notifications/channels", middleware.AuthRequired(cfg.JWTSecret), notificationHandler.CreateChannel)
+			forms.PUT("/:id/notifications/channels/:channelId", middleware.AuthRequired(cfg.JWTSecret), notificationHandler.UpdateChannel)
+			forms.DELETE("/:id/notifications/channels/:channelId", middleware.AuthRequired(cfg.JWTSecret), notificationHandler.DeleteChannel)
+			forms.POST("/:id/notifications/channels/:channelId/test", middleware.AuthRequired(cfg.JWTSecret), notificationHandler.SendTestChannelMessage)
+			forms.GET("/:id/protection-status", middleware.AuthRequired(cfg.JWTSecret), protectionHandler.GetProtectionStatus)
+
+			// Previews of drafts shared with people without accounts
+			forms.POST("/:id/preview-token", middleware.AuthRequired(cfg.JWTSecret), previewHandler.IssuePreviewToken)
+			forms.GET("/:id/preview-tokens", middleware.AuthRequired(cfg.JWTSecret), previewHandler.ListPreviewTokens)
+			forms.DELETE("/:id/preview-tokens/:tokenId", middleware.AuthRequired(cfg.JWTSecret), previewHandler.RevokePreviewToken)
+			forms.GET("/:id/activity", middleware.AuthRequired(cfg.JWTSecret), activityHandler.ListActivity)
+
+			// Response exports, with the files of file questions bundled
+			forms.POST("/:id/exports", middleware.AuthRequired(cfg.JWTSecret), exportHandler.StartExport)
+			forms.GET("/:id/exports/:jobId", middleware.AuthRequired(cfg.JWTSecret), exportHandler.GetExport)
+			forms.DELETE("/:id/exports/:jobId", middleware.AuthRequired(cfg.JWTSecret), exportHandler.CancelExport)
+			forms.GET("/:id/exports/:jobId/download", middleware.AuthRequired(cfg.JWTSecret), exportHandler.DownloadExport)
+
+			// Collaborators and their roles
+			forms.POST("/:id/collaborators", middleware.AuthRequired(cfg.JWTSecret), collaboratorHandler.InviteCollaborator)
+			forms.GET("/:id/collaborators", middleware.AuthRequired(cfg.JWTSecret), collaboratorHandler.ListCollaborators)
+			forms.PUT("/:id/collaborators/:collaboratorId", middleware.AuthRequired(cfg.JWTSecret), collaboratorHandler.UpdateCollaborator)
+			forms.DELETE("/:id/collaborators/:collaboratorId", middleware.AuthRequired(cfg.JWTSecret), collaboratorHandler.RemoveCollaborator)
+
+			// Scheduled reports
+			forms.PUT("/:id/reports/schedule", middleware.AuthRequired(cfg.JWTSecret), reportHandler.UpsertSchedule)
+			forms.GET("/:id/reports/schedule", middleware.AuthRequired(cfg.JWTSecret), reportHandler.GetSchedule)
+			forms.DELETE("/:id/reports/schedule", middleware.AuthRequired(cfg.JWTSecret), reportHandler.DeleteSchedule)
+			forms.GET("/:id/reports", middleware.AuthRequired(cfg.JWTSecret), reportHandler.ListReports)
+
+			// Questions copied from the question bank
+			forms.POST("/:id/questions/from-library", middleware.AuthRequired(cfg.JWTSecret), libraryHandler.InsertFromLibrary)
+
+			// Renames of the stable keys of questions
+			forms.POST("/:id/questions/:qid/rename-key", middleware.AuthRequired(cfg.JWTSecret), formHandler.RenameQuestionKey)
+
+			// Transfers to other owners and organizations
+			forms.POST("/:id/transfer", middleware.AuthRequired(cfg.JWTSecret), transferHandler.RequestTransfer)
+
+			// Bulk deletions of responses, confirmed by a dry run
+			forms.POST("/:id/responses/bulk-delete", middleware.AuthRequired(cfg.JWTSecret), responseDeletionHandler.BulkDeleteResponses)
+			forms.GET("/:id/responses/bulk-delete/:jobId", middleware.AuthRequired(cfg.JWTSecret), responseDeletionHandler.GetResponseDeletion)
+			forms.POST("/:id/responses/bulk-delete/:jobId/resume", middleware.AuthRequired(cfg.JWTSecret), responseDeletionHandler.ResumeResponseDeletion)
+			forms.DELETE("/:id/responses/test", middleware.AuthRequired(cfg.JWTSecret), responseDeletionHandler.PurgeTestResponses)
+
+			// File question uploads
+			forms.POST("/:id/questions/:qid/upload-url", middleware.OptionalAuth(cfg.JWTSecret), uploadHandler.CreateUploadURL)
+
+			// Autosaved response drafts
+			forms.PUT("/:id/responses/draft", middleware.OptionalAuth(cfg.JWTSecret), draftHandler.SaveDraft)
+			forms.GET("/:id/responses/draft", middleware.OptionalAuth(cfg.JWTSecret), draftHandler.GetDraft)
+		}
+
+		// Published forms resolved from the slugs of their public URLs, and
+		// embedded on the sites their owners allow, their funnel beacons and
+		// public results, the previews of drafts and the exports downloaded
+		// with single-use links
+		public := api.Group("/public/forms")
+		{
+			public.GET("/by-slug/:slug", formHandler.GetFormBySlug)
+			public.GET("/preview", previewHandler.GetFormPreview)
+			public.GET("/:id/embed.js", embedHandler.GetEmbedScript)
+			public.GET("/:id/definition", embedHandler.GetEmbedDefinition)
+			public.POST("/:id/beacon", funnelHandler.Beacon)
+			public.GET("/:id/results", resultsHandler.GetPublicResults)
+			public.GET("/:id/exports/:jobId/download", exportHandler.DownloadSignedExport)
+		}
+
+		// Drill-down analytics of the responses of forms, and their funnels
+		formAnalytics := api.Group("/analytics/forms")
+		{
+			formAnalytics.POST("/:id/query", middleware.AuthRequired(cfg.JWTSecret), analyticsHandler.Query)
+			formAnalytics.GET("/:id/timings", middleware.AuthRequired(cfg.JWTSecret), analyticsHandler.QuestionTimings)
+			formAnalytics.GET("/:id/funnel", middleware.AuthRequired(cfg.JWTSecret), funnelHandler.GetFunnel)
+		}
+
+		// Organizations and their members
+		organizations := api.Group("/organizations")
+		{
+			organizations.POST("", middleware.AuthRequired(cfg.JWTSecret), organizationHandler.CreateOrganization)
+			organizations.GET("", middleware.AuthRequired(cfg.JWTSecret), organizationHandler.ListOrganizations)
+			organizations.POST("/:id/members", middleware.AuthRequired(cfg.JWTSecret), organizationHandler.InviteMember)
+			organizations.GET("/:id/members", middleware.AuthRequired(cfg.JWTSecret), organizationHandler.ListMembers)
+		}
+
+		// Transfers of forms from and to the caller and their organizations
+		transfers := api.Group("/transfers")
+		{
+			transfers.GET("", middleware.AuthRequired(cfg.JWTSecret), transferHandler.ListTransfers)
+			transfers.POST("/:id/accept", middleware.AuthRequired(cfg.JWTSecret), transferHandler.AcceptTransfer)
+			transfers.POST("/:id/cancel", middleware.AuthRequired(cfg.JWTSecret), transferHandler.CancelTransfer)
+		}
+
+		// The usage of the organization against its plan
+		api.GET("/usage", middleware.AuthRequired(cfg.JWTSecret), usageHandler.GetOwnUsage)
+
+		// The question bank of the organization
+		library := api.Group("/library/questions")
+		{
+			library.POST("", middleware.AuthRequired(cfg.JWTSecret), libraryHandler.CreateLibraryQuestion)
+			library.GET("", middleware.AuthRequired(cfg.JWTSecret), libraryHandler.SearchLibraryQuestions)
+			library.GET("/:id", middleware.AuthRequired(cfg.JWTSecret), libraryHandler.GetLibraryQuestion)
+			library.PUT("/:id", middleware.AuthRequired(cfg.JWTSecret), libraryHandler.UpdateLibraryQuestion)
+			library.DELETE("/:id", middleware.AuthRequired(cfg.JWTSecret), libraryHandler.DeleteLibraryQuestion)
+			library.GET("/:id/usages", middleware.AuthRequired(cfg.JWTSecret), libraryHandler.ListLibraryQuestionUsages)
+			library.POST("/:id/propagate", middleware.AuthRequired(cfg.JWTSecret), libraryHandler.PropagateLibraryQuestion)
+		}
+
+		// Uploaded files
+		files := api.Group("/files")
+		{
+			files.GET("/:id/scan-status", middleware.AuthRequired(cfg.JWTSecret), fileHandler.GetScanStatus)
+			files.GET("/:id/download", middleware.AuthRequired(cfg.JWTSecret), fileHandler.DownloadFile)
+		}
+
+		// The storage proxy serves the pre-signed uploads and downloads the
+		// store can't sign
+		if proxy, ok := container.Storage.(*storage.Proxy); ok {
+			root.PUT(storage.ProxyPath+"/*key", gin.WrapH(proxy))
+			root.GET(storage.ProxyPath+"/*key", gin.WrapH(proxy))
 		}
 	}
 
-	return router
+	// Dev builds serve the route table; the committed manifest is regenerated
+	// with go generate
+	if gin.Mode() != gin.ReleaseMode {
+		router.GET("/debug/routes", routes.Handler())
+	}
+
+	// The spec is generated from the handler annotations with go generate.
+	// It documents the API rather than being part of it, so it is not
+	// recorded in the route table.
+	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+
+	// Prometheus scrapes the metrics, including the database pool, directly
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	return router, routes
+}
+
+// healthCheck reports the service status
+//
+// @Summary Health check
+// @Tags    health
+// @Produce json
+// @Success 200 {object} handlers.HealthResponse
+// @Router  /health [get]
+func healthCheck(c *gin.Context) {
+	c.JSON(http.StatusOK, handlers.HealthResponse{
+		Status:       "healthy",
+		Service:      "form-service",
+		Timestamp:    time.Now().UTC().Format(time.RFC3339),
+		Version:      "1.0.0",
+		Architecture: "Clean Architecture with SOLID Principles",
+	})
 }
 
 // startServerGracefully starts the server with graceful shutdown support
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/docs"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/handlers"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/health"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/routetable"
+)
+
+// ginParam matches the :name and *name segments of gin paths
+var ginParam = regexp.MustCompile(`[:*]([^/]+)`)
+
+// TestSwaggerSpecMatchesRoutes fails when a route is registered without being
+// documented, or documented without being registered. Regenerate the spec
+// with go generate after changing the routes or the handler annotations.
+func TestSwaggerSpecMatchesRoutes(t *testing.T) {
+	var spec struct {
+		Paths map[string]map[string]struct {
+			Security []map[string][]string `json:"security"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal([]byte(docs.SwaggerInfo.ReadDoc()), &spec); err != nil {
+		t.Fatalf("generated spec is not valid JSON: %v", err)
+	}
+
+	documented := make(map[string]bool)
+	for path, operations := range spec.Paths {
+		for method, operation := range operations {
+			documented[strings.ToUpper(method)+" "+path] = len(operation.Security) > 0
+		}
+	}
+
+	_, routes := setupRouter(&ApplicationContainer{
+		Config:        &config.Config{},
+		FormHandler:   handlers.NewFormHandler(nil, nil, 0),
+		UploadHandler: handlers.NewUploadHandler(nil),
+		FileHandler:   handlers.NewFileHandler(nil),
+		DraftHandler:  handlers.NewDraftHandler(nil),
+		Readiness:     health.NewChecker(0),
+	})
+
+	var undocumented []string
+	for _, route := range routes.Manifest().Routes {
+		operation := route.Method + " " + ginParam.ReplaceAllString(route.Path, "{$1}")
+		secured, ok := documented[operation]
+		if !ok {
+			undocumented = append(undocumented, operation)
+			continue
+		}
+		delete(documented, operation)
+
+		if wantSecured := route.Auth != routetable.AuthPublic; secured != wantSecured {
+			t.Errorf("%s: spec security is %v but the route is %s", operation, secured, route.Auth)
+		}
+	}
+
+	var unregistered []string
+	for operation := range documented {
+		unregistered = append(unregistered, operation)
+	}
+	sort.Strings(unregistered)
+
+	if len(undocumented) > 0 {
+		t.Errorf("routes missing from the swagger spec: %v", undocumented)
+	}
+	if len(unregistered) > 0 {
+		t.Errorf("swagger operations without a route: %v", unregistered)
+	}
+}
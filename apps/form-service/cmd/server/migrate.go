@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/config"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/database"
+)
+
+const migrateUsage = `Usage: form-service migrate COMMAND
+
+Commands:
+  up           apply every pending migration
+  down N       revert the last N migrations
+  status       show the schema version and the pending migrations
+  force V      record version V as applied and clear the dirty flag, after
+               repairing a failed migration by hand
+
+The database is configured by DATABASE_URL.
+`
+
+// runMigrate runs the migrate subcommand with the arguments following it
+func runMigrate(args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(flags.Output(), migrateUsage) }
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flags.Args()
+	if len(args) == 0 {
+		flags.Usage()
+		return errors.New("missing migrate command")
+	}
+
+	// Validate the arguments before connecting
+	var n uint64
+	switch args[0] {
+	case "up", "status":
+		if len(args) != 1 {
+			return fmt.Errorf("migrate %s takes no argument", args[0])
+		}
+	case "down", "force":
+		if len(args) != 2 {
+			return fmt.Errorf("migrate %s takes one argument", args[0])
+		}
+		var err error
+		if n, err = strconv.ParseUint(args[1], 10, 32); err != nil || (args[0] == "down" && n == 0) {
+			return fmt.Errorf("invalid %s argument %q", args[0], args[1])
+		}
+	default:
+		flags.Usage()
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	db, err := database.Connect(cfg.DatabaseURL, cfg.Database)
+	if err != nil {
+		return err
+	}
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		return migrator.Up(ctx)
+	case "down":
+		return migrator.Down(ctx, int(n))
+	case "force":
+		return migrator.Force(ctx, uint(n))
+	default:
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		printStatus(os.Stdout, status)
+		return nil
+	}
+}
+
+// printStatus prints the schema version and every migration of the binary
+func printStatus(w io.Writer, status database.MigrationStatus) {
+	fmt.Fprintf(w, "version %d, latest %d", status.Version, status.Latest)
+	if status.Dirty {
+		fmt.Fprint(w, " (dirty)")
+	}
+	fmt.Fprintln(w)
+	for _, m := range status.Applied {
+		fmt.Fprintf(w, "applied  %s\n", m)
+	}
+	for _, m := range status.Pending {
+		fmt.Fprintf(w, "pending  %s\n", m)
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestRunMigrateRejectsArguments covers the arguments refused before the
+// database is connected
+func TestRunMigrateRejectsArguments(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, "missing migrate command"},
+		{[]string{"sideways"}, `unknown migrate command "sideways"`},
+		{[]string{"up", "1"}, "takes no argument"},
+		{[]string{"down"}, "takes one argument"},
+		{[]string{"down", "0"}, `invalid down argument "0"`},
+		{[]string{"force", "-1"}, `invalid force argument "-1"`},
+	}
+	for _, tt := range tests {
+		err := runMigrate(tt.args)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("migrate %v: err = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+}
@@ -9,241 +9,212 @@ const docTemplate = `{
     "info": {
         "description": "{{escape .Description}}",
         "title": "{{.Title}}",
-        "contact": {
-            "name": "Form Service Team",
-            "email": "form-service@example.com"
-        },
-        "license": {
-            "name": "MIT",
-            "url": "https://opensource.org/licenses/MIT"
-        },
+        "contact": {},
         "version": "{{.Version}}"
     },
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/forms": {
+        "/api/v1/analytics/forms/{id}/funnel": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get a paginated list of forms with optional filtering",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Reports the sessions of respondents viewing, starting and submitting a form per UTC day from from to to, both included, as YYYY-MM-DD; to defaults to today and from to 29 days before to, and at most 366 days are reported at once. A session is counted once per stage, on the day it reached it; submissions are the responses of the form, test submissions aside. start_rate is starts per view, completion_rate submissions per start and conversion_rate submissions per view, capped at 1 as a session may reach a stage the day after the previous one. Today is flagged partial; the rates of the totals leave it out unless it is the only day. Counts are read from the event store projection, so they lag beacons and submissions by the projection delay.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Forms"
+                    "analytics"
                 ],
-                "summary": "List forms",
+                "summary": "Get the funnel of a form",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "default": 1,
-                        "description": "Page number",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 20,
-                        "description": "Page size",
-                        "name": "pageSize",
-                        "in": "query"
-                    },
-                    {
-                        "enum": [
-                            "draft",
-                            "published",
-                            "closed",
-                            "archived"
-                        ],
-                        "type": "string",
-                        "description": "Filter by status",
-                        "name": "status",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filter by category",
-                        "name": "category",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Search in title and description",
-                        "name": "search",
-                        "in": "query"
-                    },
-                    {
-                        "enum": [
-                            "created_at",
-                            "updated_at",
-                            "title",
-                            "response_count"
-                        ],
                         "type": "string",
-                        "default": "created_at",
-                        "description": "Sort field",
-                        "name": "sortBy",
-                        "in": "query"
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "enum": [
-                            "asc",
-                            "desc"
-                        ],
                         "type": "string",
-                        "default": "desc",
-                        "description": "Sort order",
-                        "name": "sortOrder",
+                        "format": "date",
+                        "description": "First day, YYYY-MM-DD",
+                        "name": "from",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Filter by creator user ID",
-                        "name": "createdBy",
+                        "format": "date",
+                        "description": "Last day, YYYY-MM-DD",
+                        "name": "to",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Forms retrieved successfully",
+                        "description": "OK",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/dto.PaginatedResponse"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/dto.FormSummaryDTO"
-                                            }
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/FunnelReport"
                         }
                     },
                     "400": {
-                        "description": "Invalid request parameters",
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/api/v1/analytics/forms/{id}/query": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a new form with questions and settings",
+                "description": "Counts the answers to a question among the responses matching every filter, submitted in [from, to), per group. Groups are the answers to another question, the responses not answering it in the null group, or UTC time buckets. Percentages are of the responses of the group; those of a multiple choice question may add up to more than 100. At most 1000 group and answer pairs are returned, truncated is set when there were more. Filtering or grouping on a text, textarea or email question with more than ANALYTICS_QUERY_MAX_DISTINCT_VALUES distinct answers is refused with 422. Responses are read from the event store projection, so they lag submissions by the projection delay. With Accept: text/csv or format=csv the result is returned as CSV, a row per group and answer.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/csv"
                 ],
                 "tags": [
-                    "Forms"
+                    "analytics"
                 ],
-                "summary": "Create a new form",
+                "summary": "Query form responses",
                 "parameters": [
                     {
-                        "description": "Form creation request",
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Query",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.CreateFormRequestDTO"
+                            "$ref": "#/definitions/analytics.Query"
                         }
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "csv to render the result as CSV",
+                        "name": "format",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Form created successfully",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/dto.SuccessResponse"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/dto.FormResponseDTO"
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/analytics.QueryResult"
                         }
                     },
                     "400": {
-                        "description": "Invalid request",
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "422": {
-                        "description": "Validation failed",
+                        "description": "Unprocessable Entity",
                         "schema": {
-                            "$ref": "#/definitions/dto.ValidationErrorDTO"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/forms/{id}": {
+        "/api/v1/analytics/forms/{id}/timings": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieve a form with all its details",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Reports, for each question of a form in form order, the median and 90th percentile of the milliseconds it held focus and the mean times respondents returned to it, over the timing beacons of the latest ANALYTICS_TIMING_SAMPLE_LIMIT submissions sending them, test submissions aside. Drop-off is read from the drafts of signed-in respondents left idle for DRAFT_ABANDON_AFTER: each stops at its last answered question, and the drop-off rate of a question is the share of the respondents reaching it, responses and abandoned drafts answering it or a later question, who stopped there. The drafts of anonymous respondents are not counted. Timings are only reported to the users who may view the form; public results never include them.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Forms"
+                    "analytics"
                 ],
-                "summary": "Get form by ID",
+                "summary": "Get question timings",
                 "parameters": [
                     {
                         "type": "string",
@@ -256,164 +227,143 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Form retrieved successfully",
+                        "description": "OK",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/dto.SuccessResponse"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/dto.FormResponseDTO"
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/TimingReport"
                         }
                     },
                     "400": {
-                        "description": "Invalid form ID",
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Form not found",
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/api/v1/files/{id}/download": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update an existing form's details",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Redirects to a short-lived download URL. Files still being scanned return 409 with Retry-After.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Forms"
+                    "files"
                 ],
-                "summary": "Update form",
+                "summary": "Download a file",
                 "parameters": [
                     {
                         "type": "string",
                         "format": "uuid",
-                        "description": "Form ID",
+                        "description": "File ID",
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Form update request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.UpdateFormRequestDTO"
-                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Form updated successfully",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/dto.SuccessResponse"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/dto.FormResponseDTO"
-                                        }
-                                    }
-                                }
-                            ]
+                    "307": {
+                        "description": "Temporary Redirect",
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "Download URL"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid request",
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "403": {
-                        "description": "Forbidden - not form owner",
+                        "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Form not found",
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
-                    "422": {
-                        "description": "Validation failed",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
-                            "$ref": "#/definitions/dto.ValidationErrorDTO"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "451": {
+                        "description": "Unavailable For Legal Reasons",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/api/v1/files/{id}/scan-status": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Delete a form and all its responses",
-                "consumes": [
-                    "application/json"
-                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Forms"
+                    "files"
                 ],
-                "summary": "Delete form",
+                "summary": "Get the antivirus scan status of a file",
                 "parameters": [
                     {
                         "type": "string",
                         "format": "uuid",
-                        "description": "Form ID",
+                        "description": "File ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -421,58 +371,124 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Form deleted successfully",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.SuccessResponse"
+                            "$ref": "#/definitions/service.FileScanStatusResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid form ID",
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "403": {
-                        "description": "Forbidden - not form owner",
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/forms": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "The forms of the caller, or with shared=true the forms shared with the caller as a collaborator. With Accept: text/csv or format=csv every form is returned as CSV, one row per form without the pagination envelope, up to CSV_ROW_LIMIT forms; more are refused with 413.",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "forms"
+                ],
+                "summary": "List forms",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "List the forms shared with the caller",
+                        "name": "shared",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page, 1 by default",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, 10 by default and at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "csv to render the forms as CSV",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.PaginatedFormsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Form not found",
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
-                    "409": {
-                        "description": "Cannot delete published form",
+                    "413": {
+                        "description": "Request Entity Too Large",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.CSVTooLargeResponse"
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
                 }
-            }
-        },
-        "/forms/{id}/archive": {
+            },
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Archive a form for long-term storage",
+                "description": "The form is created in the organization the request acts in, or in the personal organization of the user. Organizations with as many active forms as their plan allows get 402 with code upgrade_required until a form is closed.",
                 "consumes": [
                     "application/json"
                 ],
@@ -480,175 +496,144 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Forms"
+                    "forms"
                 ],
-                "summary": "Archive form",
+                "summary": "Create a form",
                 "parameters": [
                     {
-                        "type": "string",
-                        "format": "uuid",
-                        "description": "Form ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Form to create",
+                        "name": "form",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.CreateFormRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Form archived successfully",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/dto.SuccessResponse"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/dto.FormResponseDTO"
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/handlers.FormResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid form ID",
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden - not form owner",
+                    "402": {
+                        "description": "Payment Required",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.QuotaErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Form not found",
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/forms/{id}/close": {
-            "post": {
+        "/api/v1/forms/changes": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Close a form to stop accepting new responses",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Forms created, updated or deleted after the cursor, oldest first. Question changes update their form. Pass next_cursor as since to get the following changes; the feed is at least once, so a form updated again shows again. Changes of the last few seconds are held back until concurrent writes have committed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Forms"
+                    "forms"
                 ],
-                "summary": "Close form",
+                "summary": "List form changes",
                 "parameters": [
                     {
                         "type": "string",
-                        "format": "uuid",
-                        "description": "Form ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Cursor returned as next_cursor, empty for the start of the feed",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, 100 by default and at most 500",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Form closed successfully",
+                        "description": "OK",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/dto.SuccessResponse"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/dto.FormResponseDTO"
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/service.FormChangesResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid form ID",
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
-                        }
-                    },
-                    "403": {
-                        "description": "Forbidden - not form owner",
-                        "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Form not found",
-                        "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
-                        }
-                    },
-                    "409": {
-                        "description": "Form not published",
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/forms/{id}/duplicate": {
-            "post": {
+        "/api/v1/forms/{id}": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a copy of an existing form",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Authentication is optional. With locale, the form is returned translated as a service.LocalizedFormResponse and Content-Language is set. Responses carry a strong ETag that changes with any change to the form returned, publishing included; send it back in If-None-Match to get 304 without the body while the form is unchanged.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Forms"
+                    "forms"
                 ],
-                "summary": "Duplicate form",
+                "summary": "Get a form",
                 "parameters": [
                     {
                         "type": "string",
@@ -657,62 +642,69 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Locale to translate the form into",
+                        "name": "locale",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the copy the client holds",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Form duplicated successfully",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/dto.SuccessResponse"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/dto.FormResponseDTO"
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/handlers.GetFormResponse"
                         }
                     },
+                    "304": {
+                        "description": "Not Modified"
+                    },
                     "400": {
-                        "description": "Invalid form ID",
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Form not found",
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
                 }
-            }
-        },
-        "/forms/{id}/publish": {
-            "post": {
+            },
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Publish a form to make it available for responses",
+                "description": "rules replaces the cross-field validation rules of the form. Rules of type comparison, sum_equals, at_least and expression read questions by the keys they declare in questions; expressions may use numbers, \"strings\", true, false, + - * /, comparisons, \u0026\u0026 || !, parentheses, answered(key) and count(key). Rules reading unknown questions, or questions whose type doesn't fit, get 400.",
                 "consumes": [
                     "application/json"
                 ],
@@ -720,9 +712,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Forms"
+                    "forms"
                 ],
-                "summary": "Publish form",
+                "summary": "Update a form",
                 "parameters": [
                     {
                         "type": "string",
@@ -733,90 +725,73 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Publish form request",
-                        "name": "request",
+                        "description": "Fields to update",
+                        "name": "form",
                         "in": "body",
+                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.PublishFormRequestDTO"
+                            "$ref": "#/definitions/service.UpdateFormRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Form published successfully",
+                        "description": "OK",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/dto.SuccessResponse"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/dto.FormResponseDTO"
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/handlers.FormResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid request",
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "403": {
-                        "description": "Forbidden - not form owner",
+                        "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Form not found",
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "409": {
-                        "description": "Form already published",
+                        "description": "Conflict",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
                 }
-            }
-        },
-        "/forms/{id}/statistics": {
-            "get": {
+            },
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get detailed statistics for a form",
-                "consumes": [
-                    "application/json"
-                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Forms"
+                    "forms"
                 ],
-                "summary": "Get form statistics",
+                "summary": "Delete a form",
                 "parameters": [
                     {
                         "type": "string",
@@ -829,117 +804,212 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Statistics retrieved successfully",
+                        "description": "OK",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/dto.SuccessResponse"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/dto.FormStatisticsDTO"
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/handlers.MessageResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid form ID",
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "403": {
-                        "description": "Forbidden - not form owner",
+                        "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Form not found",
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/health": {
+        "/api/v1/forms/{id}/activity": {
             "get": {
-                "description": "Comprehensive health check including dependencies",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Who changed what in the definition of the form, and when, newest first. Updates name the fields that changed, never their values; responses are never part of the feed. Pass next_cursor as cursor for the following page. The feed keeps the latest activities of each form only. Builders open on the form also receive new activities as activity frames of the collaboration WebSocket. Requires the owner or a collaborator.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Health"
+                    "forms"
+                ],
+                "summary": "List form activity",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned as next_cursor, empty for the newest activities",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, 50 by default and at most 200",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "enum": [
+                                "form.created",
+                                "form.updated",
+                                "form.published",
+                                "form.translation_updated",
+                                "question.added",
+                                "question.updated",
+                                "question.deleted",
+                                "questions.reordered",
+                                "form.merged"
+                            ],
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Keep these actions only, repeated or comma separated",
+                        "name": "action",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Health check",
                 "responses": {
                     "200": {
-                        "description": "Service is healthy",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.HealthCheckResponseDTO"
+                            "$ref": "#/definitions/service.ActivityPage"
                         }
                     },
-                    "503": {
-                        "description": "Service is unhealthy",
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/dto.HealthCheckResponseDTO"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/health/live": {
+        "/api/v1/forms/{id}/collaborators": {
             "get": {
-                "description": "Check if service is alive and responsive",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Health"
+                    "collaborators"
+                ],
+                "summary": "List collaborators",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Liveness check",
                 "responses": {
                     "200": {
-                        "description": "Service is alive",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.SuccessResponse"
+                            "$ref": "#/definitions/handlers.CollaboratorListResponse"
                         }
                     },
-                    "503": {
-                        "description": "Service is not responsive",
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
                 }
-            }
-        },
-        "/health/ready": {
-            "get": {
-                "description": "Check if service is ready to accept requests",
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Grants a user a role on the form. Viewers read the form, editors also change it and its questions, managers also publish and delete it and manage its collaborators. Requires the owner or a manager.",
                 "consumes": [
                     "application/json"
                 ],
@@ -947,28 +1017,82 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Health"
+                    "collaborators"
+                ],
+                "summary": "Invite a collaborator",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Collaborator",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.InviteCollaboratorRequest"
+                        }
+                    }
                 ],
-                "summary": "Readiness check",
                 "responses": {
-                    "200": {
-                        "description": "Service is ready",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/dto.SuccessResponse"
+                            "$ref": "#/definitions/models.Collaborator"
                         }
                     },
-                    "503": {
-                        "description": "Service is not ready",
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/metrics": {
-            "get": {
-                "description": "Get performance and usage metrics",
+        "/api/v1/forms/{id}/collaborators/{collaboratorId}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Requires the owner or a manager.",
                 "consumes": [
                     "application/json"
                 ],
@@ -976,50 +1100,89 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Monitoring"
+                    "collaborators"
+                ],
+                "summary": "Change the role of a collaborator",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Collaborator ID",
+                        "name": "collaboratorId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Role",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.UpdateCollaboratorRequest"
+                        }
+                    }
                 ],
-                "summary": "Get service metrics",
                 "responses": {
                     "200": {
-                        "description": "Metrics retrieved successfully",
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Collaborator"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/dto.SuccessResponse"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/dto.HealthMetricsDTO"
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
                 }
-            }
-        },
-        "/public/forms/{id}": {
-            "get": {
-                "description": "Retrieve a public form that can be filled by anyone",
-                "consumes": [
-                    "application/json"
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Revokes the access of the collaborator. Requires the owner or a manager.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Public"
+                    "collaborators"
                 ],
-                "summary": "Get public form",
+                "summary": "Remove a collaborator",
                 "parameters": [
                     {
                         "type": "string",
@@ -1028,908 +1191,8585 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Collaborator ID",
+                        "name": "collaboratorId",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Public form retrieved successfully",
+                        "description": "OK",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/dto.SuccessResponse"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/dto.FormResponseDTO"
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/handlers.MessageResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid form ID",
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Form not found or not public",
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "dto.ConditionDTO": {
-            "type": "object",
-            "required": [
-                "operator",
-                "questionId"
-            ],
-            "properties": {
-                "operator": {
-                    "type": "string",
-                    "enum": [
-                        "equals",
-                        "not_equals",
-                        "contains",
-                        "not_contains",
-                        "greater_than",
-                        "less_than"
-                    ],
-                    "example": "equals"
-                },
-                "questionId": {
-                    "type": "string",
-                    "example": "q1"
-                },
-                "value": {
-                    "type": "string",
-                    "example": "yes"
-                }
-            }
         },
-        "dto.ConditionalLogicDTO": {
-            "type": "object",
-            "properties": {
-                "hideIf": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/dto.ConditionDTO"
+        "/api/v1/forms/{id}/exports": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
                     }
+                ],
+                "description": "Queues the export of the responses of the form as CSV or XLSX, a row for each response. Exports are scheduled fairly across organizations, a few of each running at a time; low priority exports only run when no normal export can run instead. With include_files, the export is a ZIP archive of the responses file and a folder for each response ID holding the files answering its file questions, named after the key of their question (q and its position) and their file name. Files missing from storage or quarantined by the antivirus scan are replaced by a text file giving the reason. Exports growing past the maximum size fail. Poll the job for its progress and download link. Requires the owner or a collaborator.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "forms"
+                ],
+                "summary": "Export responses",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Export options",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.ExportRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/models.ExportJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/forms/{id}/exports/{jobId}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the status of the export and its progress. Queued exports give their place among the exports of the organization waiting to run in queue_position. Running exports give the responses exported, the bytes written, the files archived and those replaced by a placeholder. Completed exports come with the download_url signing links to download them. Failed exports give the reason in error. Requires the owner or a collaborator.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "forms"
+                ],
+                "summary": "Get a response export",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Export job ID",
+                        "name": "jobId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ExportJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Cancels a queued or running export. A running export stops at its next batch of responses and what it wrote is deleted. Requires whoever started the export, or the owner or an editor.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "forms"
+                ],
+                "summary": "Cancel a response export",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Export job ID",
+                        "name": "jobId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ExportJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/forms/{id}/exports/{jobId}/download": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns a short-lived link downloading the completed export without authentication, Range requests included. The link points at the object storage, or at the public download endpoint when links are single-use, and stops working once expired. Deployments streaming exports through the service return the export itself instead. Requires the owner or a collaborator.",
+                "produces": [
+                    "application/json",
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "forms"
+                ],
+                "summary": "Download a response export",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Export job ID",
+                        "name": "jobId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.ExportDownload"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/forms/{id}/merge": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Merges the changes made on base_version into the current form, three-way, path by path. A change applies when the server value is still its base, is skipped when the server already has its value, and is returned as a conflict otherwise: reason edited for a value changed since, or a question removed while changed since; deleted for changes to a question deleted since; reordered for a reorder of questions reordered otherwise since; exists for a question added under the ID of another one. Question edits, adds and removes merge with reorders. The changes applied are saved at once, bump the version once and are pushed to the builders open on the form; conflicts are left for the editor to resolve and merge again. Changes the form endpoints would reject get 400, as a whole.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "forms"
+                ],
+                "summary": "Merge builder changes into a form",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Base version and changes",
+                        "name": "merge",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.MergeFormRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.MergeFormResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "The form kept changing during the merge",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/forms/{id}/notifications/channels": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the Slack and Teams channels of the form. Webhook URLs are never returned, only a hint of each.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "forms"
+                ],
+                "summary": "List the notification channels of a form",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.NotificationChannelListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Adds a Slack or Teams channel the events of the form are posted to through an incoming webhook, stored encrypted. Messages show the form title, the responses of the day and a link; answers are only shown with include_answer_summary. Nothing is posted during the quiet hours, in the time zone of the channel. Create the channel disabled and send a test message to check the webhook before enabling it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "forms"
+                ],
+                "summary": "Add a notification channel",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Notification channel",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.CreateChannelRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.NotificationChannel"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/forms/{id}/notifications/channels/{channelId}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Changes the fields of the channel that are set; a webhook_url replaces the webhook.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "forms"
+                ],
+                "summary": "Update a notification channel",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Channel ID",
+                        "name": "channelId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Changes",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.UpdateChannelRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.NotificationChannel"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "forms"
+                ],
+                "summary": "Delete a notification channel",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Channel ID",
+                        "name": "channelId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/forms/{id}/notifications/channels/{channelId}/test": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Queues a test message to the channel, whether it is enabled or not and outside its quiet hours, so owners can check its webhook before enabling it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "forms"
+                ],
+                "summary": "Send a test message to a notification channel",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Channel ID",
+                        "name": "channelId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/service.TestNotificationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/forms/{id}/notifications/test": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Queues a test email to the owner email of the form notification settings.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "forms"
+                ],
+                "summary": "Send a test notification",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/service.TestNotificationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/forms/{id}/preview-token": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Issues a token sharing the current definition of the form with people without accounts, read only. Tokens expire after 7 days unless expires_in asks for less, and never after the maximum the service allows. The token is only returned here. Requires the owner or an editor; deleted forms are 404.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "previews"
+                ],
+                "summary": "Issue a preview token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Expiry",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/service.IssuePreviewTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/service.IssuedPreviewToken"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/forms/{id}/preview-tokens": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the preview tokens of the form, most recent first, expired and revoked ones included. The tokens themselves are not returned. Requires the owner or an editor.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "previews"
+                ],
+                "summary": "List preview tokens",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PreviewTokenListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/forms/{id}/preview-tokens/{tokenId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revokes the token; previews with it are refused from then on. Revoking a revoked token changes nothing. Requires the owner or an editor.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "previews"
+                ],
+                "summary": "Revoke a preview token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Preview token ID",
+                        "name": "tokenId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PreviewToken"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/forms/{id}/protection-status": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Whether a surge of submissions throttled the form, the spam protection submissions must pass and the throttling thresholds. Throttled forms require the CAPTCHA challenge and a stricter per-IP rate limit until the submission rate normalizes.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "forms"
+                ],
+                "summary": "Get the protection status of a form",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.ProtectionStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/forms/{id}/publish": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "forms"
+                ],
+                "summary": "Publish a form",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PublishFormResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/forms/{id}/questions/from-library": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Copies library questions of the organization of the form into it, in the order listed, starting at position. Questions from that position on move down; without a position the copies are appended. Each copy records the library question and version it was copied from.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "library"
+                ],
+                "summary": "Insert questions from the library",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Library questions",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.InsertFromLibraryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.LibraryInsertResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Questions were added to the form concurrently",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/forms/{id}/questions/{qid}/rename-key": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Renames the stable key a question's responses are projected under and exports name its column after. The former key becomes an alias of the question: the answers given under it keep being aggregated with the question, and no other question of the form may take it. A question may be renamed back to one of its former keys. Keys are lowercase letters, digits and underscores, starting with a letter, at most 64 characters.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "forms"
+                ],
+                "summary": "Rename the key of a question",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Question ID",
+                        "name": "qid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New key",
+                        "name": "rename",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.RenameQuestionKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Question"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Another question of the form has or had the key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/forms/{id}/questions/{qid}/upload-url": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Authentication is optional. The returned file token is submitted as the answer.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Create a pre-signed upload URL",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Question ID",
+                        "name": "qid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "File to upload",
+                        "name": "upload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.CreateUploadURLRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/service.UploadURLResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/forms/{id}/reports": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the latest generated reports of the form, newest first, with download links valid for 15 minutes.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "List form reports",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ReportListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/forms/{id}/reports/schedule": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Get the report schedule of a form",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ReportSchedule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Enrolls the form in daily or weekly summary reports, replacing its schedule. Reports run at send_at local time in the timezone, so they keep their local time across daylight saving changes. Periods without responses are skipped unless include_empty is set.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Schedule form reports",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Report schedule",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.ReportScheduleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ReportSchedule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Removes the report schedule of the form. Reports generated so far stay available.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Stop form reports",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/forms/{id}/responses/bulk-delete": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deletes the responses of a form submitted before or after a date, listed by ID, or flagged as test submissions; a response must match every filter set. Requires the manager role. A dry run answers 200 with the number of responses matching and a confirmation token, valid for 10 minutes by default; the deletion is then started with the same filter and the token, and answered with 202. A token starts a single deletion, which deletes no more responses than its dry run counted and is refused with 409 when more match. Deleting more than the configured share of the responses of a form (50% by default) needs override_cap, from an owner or an admin of its organization. Deleted responses are released from the response quota.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "responses"
+                ],
+                "summary": "Delete responses in bulk",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Filter and confirmation",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.BulkDeleteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.ResponseDeletionPreview"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/models.ResponseDeletionJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/forms/{id}/responses/bulk-delete/{jobId}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the status of the bulk deletion and the number of responses deleted so far. Requires the manager role.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "responses"
+                ],
+                "summary": "Get a bulk deletion",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Deletion job ID",
+                        "name": "jobId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ResponseDeletionJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/forms/{id}/responses/bulk-delete/{jobId}/resume": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Queues a failed bulk deletion again. It deletes the batch it failed on first, then goes on with the responses left. Deletions that did not fail answer 409. Requires the manager role.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "responses"
+                ],
+                "summary": "Resume a bulk deletion",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Deletion job ID",
+                        "name": "jobId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/models.ResponseDeletionJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/forms/{id}/responses/draft": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "drafts"
+                ],
+                "summary": "Get a response draft",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Anonymous respondent token",
+                        "name": "X-Respondent-Token",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ResponseDraft"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Drafts belong to the signed-in user, or to the X-Respondent-Token of an anonymous respondent.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "drafts"
+                ],
+                "summary": "Autosave a response draft",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Anonymous respondent token",
+                        "name": "X-Respondent-Token",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Partial answers",
+                        "name": "draft",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.SaveDraftRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DraftSavedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/forms/{id}/responses/test": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deletes every test response of the form, submitted by its owner or collaborators with the X-Test-Submission header, without a dry run. Requires the editor role. The deletion runs as a bulk deletion, followed with its ID; forms without test responses answer 422.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "responses"
+                ],
+                "summary": "Delete the test responses of a form",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/models.ResponseDeletionJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/forms/{id}/transfer": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Requests the transfer of a form to another user or organization, who has 7 days to accept it. Requires the owner of the form, or an owner or an admin of its organization. Forms of a team organization go to its members or to another organization; forms of a personal organization move to the personal organization of the user they are transferred to. A form has at most one pending transfer.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transfers"
+                ],
+                "summary": "Transfer a form",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Target",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.TransferFormRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.FormTransfer"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "402": {
+                        "description": "Payment Required",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.QuotaErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/forms/{id}/translations/{locale}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "forms"
+                ],
+                "summary": "Create or replace a form translation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Locale of the translation",
+                        "name": "locale",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Translated strings",
+                        "name": "translation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.UpsertTranslationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FormResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/questions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the library questions whose title or description contains q, having every tag listed and of the type, most recently updated first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "library"
+                ],
+                "summary": "Search library questions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Text in the title or description",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "demographics,age",
+                        "description": "Comma separated tags, all required",
+                        "name": "tags",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Question type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Page size",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.LibraryQuestionList"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Adds a question to the library of the organization the caller acts in, or of their personal organization. The definition is validated like the questions of forms. Tags are matched case insensitively.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "library"
+                ],
+                "summary": "Add a library question",
+                "parameters": [
+                    {
+                        "description": "Library question",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.LibraryQuestionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.LibraryQuestion"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/questions/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "library"
+                ],
+                "summary": "Get a library question",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Library question ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.LibraryQuestion"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Updates the definition or tags of a library question. Changes of the definition bump its version; the forms using the previous version keep their copy until the update is propagated.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "library"
+                ],
+                "summary": "Update a library question",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Library question ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Changes",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.UpdateLibraryQuestionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.LibraryQuestion"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Removes the question from the library. The forms that copied it keep their copies.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "library"
+                ],
+                "summary": "Delete a library question",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Library question ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/questions/{id}/propagate": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Updates the copies of older versions of the library question in draft forms of the organization to its current definition. Published and closed forms are never changed; their copies are counted as skipped. Requires an owner or an admin of the organization.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "library"
+                ],
+                "summary": "Propagate a library question",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Library question ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.PropagateResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/library/questions/{id}/usages": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the questions of forms copied from an older version of the library question, with the status of their form. Only the copies in draft forms are updated by a propagation.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "library"
+                ],
+                "summary": "List outdated usages",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Library question ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.LibraryUsages"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/organizations": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "The organizations the caller is a member of, their personal organization first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "List organizations",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.OrganizationListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Creates an organization with the caller as its owner. Tokens naming it in their org_id claim act in it and only see its forms.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "Create an organization",
+                "parameters": [
+                    {
+                        "description": "Organization",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.CreateOrganizationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Organization"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/organizations/{id}/members": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Organizations the caller is not a member of are not found.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "List members",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Organization ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.MemberListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Adds a user to the organization as a member or an admin. Requires an owner or an admin. Organizations the caller is not a member of are not found.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "Invite a member",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Organization ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Member",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.InviteMemberRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrganizationMember"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/public/forms/by-slug/{slug}": {
+            "get": {
+                "description": "Public. Slugs are unique within an organization; pass organization_id when the slug may be used by several. Pass the respondent token to receive the shuffle seed of questions with randomized options, and the view_token the funnel beacons of the respondent carry. A slug replaced in the last 30 days answers 301 with the slug its form moved to in moved_to and Location. Drafts and closed forms are not found. Forms found carry an ETag, except those with honeypot protection; send it back in If-None-Match to get 304 without the body while the form is unchanged.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "forms"
+                ],
+                "summary": "Get a published form by slug",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Form slug",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Organization of the form",
+                        "name": "organization_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Anonymous respondent token the shuffle seed and view token are derived from",
+                        "name": "X-Respondent-Token",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the copy the client holds",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.ResolvedSlug"
+                        }
+                    },
+                    "301": {
+                        "description": "Moved Permanently",
+                        "schema": {
+                            "$ref": "#/definitions/service.ResolvedSlug"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/public/forms/preview": {
+            "get": {
+                "description": "Public. Returns the current definition of the form shared by the preview token, whatever its status. The response is marked with \"preview\": true, \"read_only\": true and the X-Form-Preview header; clients render a preview banner and no submit action, and the response service rejects submissions carrying a preview token.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "previews"
+                ],
+                "summary": "Preview a form",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Preview token",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.FormPreview"
+                        },
+                        "headers": {
+                            "X-Form-Preview": {
+                                "type": "string",
+                                "description": "true"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/public/forms/{id}/beacon": {
+            "post": {
+                "description": "Public. Tells the funnel of a form its respondent viewed it, once shown, or started it, at the first interaction with an answer; submissions complete the funnel on their own. view_token is the one of the definition of the form, served to clients sending X-Respondent-Token. Beacons are fire and forget: they are answered 202 before being processed, and sending one with navigator.sendBeacon, as text/plain, is fine. A session is counted once per stage; beacons of tests, set by test, the X-Test-Submission: true header or test=true, with a view token not issued for the form, or beyond FUNNEL_BEACON_RATE_LIMIT per client and form are dropped.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "embed"
+                ],
+                "summary": "Send a funnel beacon",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Beacon",
+                        "name": "beacon",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.FunnelBeacon"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "true to drop the beacon of a test",
+                        "name": "X-Test-Submission",
+                        "in": "header"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "true to drop the beacon of a test",
+                        "name": "test",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/public/forms/{id}/definition": {
+            "get": {
+                "description": "Public. Browsers are only let read it from the origins in the embed_allowed_origins setting of the form; other origins get 403. embed.frame_ancestors is the Content-Security-Policy directive to serve pages framing the form with. spam_protection is the challenge submissions must pass, if any. shuffle_seed, set when a question randomizes its options and the respondent token is sent, seeds the shuffle of those options; it is the same on every load for the respondent. view_token, set when the respondent token is sent, is carried by the funnel beacons of the respondent; it changes daily. Definitions carry an ETag, except those with honeypot protection; send it back in If-None-Match to get 304 without the body while the form is unchanged.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "embed"
+                ],
+                "summary": "Get the embed definition of a form",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Anonymous respondent token the shuffle seed and view token are derived from",
+                        "name": "X-Respondent-Token",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the copy the client holds",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.EmbedDefinitionResponse"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/public/forms/{id}/embed.js": {
+            "get": {
+                "description": "Public. Include it as \u003cscript src=\".../embed.js\" data-target=\"#container\"\u003e\u003c/script\u003e; without data-target the form renders after the script tag. The loader fetches the definition and submits responses from the embedding site, which must be allow-listed in embed_allowed_origins. It renders the CAPTCHA widget or honeypot field of forms with spam protection, and sends the funnel beacons of the form as it is viewed and started; add data-test=\"true\" to the script tag to leave a test page out of the funnel and the responses counted.",
+                "produces": [
+                    "application/javascript"
+                ],
+                "tags": [
+                    "embed"
+                ],
+                "summary": "Get the embed loader of a form",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/public/forms/{id}/exports/{jobId}/download": {
+            "get": {
+                "description": "Downloads the export a single-use link was signed for, without authentication. Range requests are served when storage allows it; otherwise the response redirects to the object storage. The link works once. Its first response sets the export_download cookie, with which Range requests past the first byte resume the download until the link expires. The link is refused once used, once expired, and once the export is cancelled.",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "public"
+                ],
+                "summary": "Download a response export with a signed link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Export job ID",
+                        "name": "jobId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Download token",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "206": {
+                        "description": "Partial Content"
+                    },
+                    "302": {
+                        "description": "Found"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/public/forms/{id}/results": {
+            "get": {
+                "description": "Public. Returns the answer distributions of the questions whose results_visibility is aggregate_public; other questions are left out. A question with fewer responses than min_responses has null responses and distribution. Distributions are cached for PUBLIC_RESULTS_CACHE_TTL, so they lag new responses by up to that long. Forms that are not published are 404.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "results"
+                ],
+                "summary": "Get the public results of a form",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.PublicResults"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/transfers": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "The transfers from and to the caller, and from and to the organizations they are an owner or an admin of, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transfers"
+                ],
+                "summary": "List transfers",
+                "parameters": [
+                    {
+                        "enum": [
+                            "pending",
+                            "accepted",
+                            "cancelled",
+                            "expired"
+                        ],
+                        "type": "string",
+                        "description": "Status",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TransferListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/transfers/{id}/accept": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Moves the form to the caller: the user it is transferred to, or an owner or an admin of the organization it is transferred to, who becomes its owner. Collaborators outside a new team organization are removed, and its slug is dropped when taken there. Transfers accepted or cancelled meanwhile conflict; expired ones are gone.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transfers"
+                ],
+                "summary": "Accept a transfer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Transfer ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.FormTransfer"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "402": {
+                        "description": "Payment Required",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.QuotaErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/transfers/{id}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Cancels a pending transfer. The side of the form cancels it, the side it is transferred to declines it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transfers"
+                ],
+                "summary": "Cancel a transfer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Transfer ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.FormTransfer"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/usage": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "The forms created, responses collected, bytes of files uploaded and exports run by the organization the request acts in, or the personal organization of the user, in a month, with the quotas of its plan and its active forms. Counts may lag by up to a minute. Requires the owner or an admin of the organization.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "Get organization usage",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "2026-10",
+                        "description": "Month as YYYY-MM, the current one by default",
+                        "name": "month",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.UsageReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Health check",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/health/live": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/health.Liveness"
+                        }
+                    }
+                }
+            }
+        },
+        "/health/ready": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    },
+                    "503": {
+                        "description": "A dependency is down",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/admin/forms/cleanup": {
+            "post": {
+                "security": [
+                    {
+                        "ServiceToken": []
+                    }
+                ],
+                "description": "Archives the forms matching the criteria: the definition of each form is exported to storage as JSON, then the form is soft deleted and form.archived is published. A cleanup selects forms last updated before inactive_before, or forms without responses, narrowed by owner, organization and status. A dry run answers 200 with the number of matching forms and a sample of their IDs; otherwise a job is queued and answered with 202. Requires the internal service token. Not routed to clients.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "internal"
+                ],
+                "summary": "Clean up abandoned forms",
+                "parameters": [
+                    {
+                        "description": "Cleanup criteria",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.CleanupRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.CleanupPreview"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/models.CleanupJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/admin/forms/cleanup/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ServiceToken": []
+                    }
+                ],
+                "description": "Returns the status of the cleanup job and the number of forms matched, archived and failed so far. Requires the internal service token. Not routed to clients.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "internal"
+                ],
+                "summary": "Get a cleanup job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Cleanup job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CleanupJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ServiceToken": []
+                    }
+                ],
+                "description": "Cancels a queued or running cleanup job. A running job stops after the page of forms it is archiving; forms archived before stay archived. Jobs that finished answer 409. Requires the internal service token. Not routed to clients.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "internal"
+                ],
+                "summary": "Cancel a cleanup job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Cleanup job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CleanupJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/admin/usage/{orgId}": {
+            "get": {
+                "description": "The usage of any organization in a month, with the quotas of its plan and its active forms. Not routed to clients.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "internal"
+                ],
+                "summary": "Get the usage of an organization",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Organization ID",
+                        "name": "orgId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "2026-10",
+                        "description": "Month as YYYY-MM, the current one by default",
+                        "name": "month",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.UsageReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/cache/purge": {
+            "post": {
+                "security": [
+                    {
+                        "ServiceToken": []
+                    }
+                ],
+                "description": "Drops the cached entries of the forms whose ID matches pattern from every cache of this instance, so they are read again from their source: public_results holds the answer distributions of public results. Answers the number of entries purged from each cache. Requires the internal service token. Not routed to clients.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "internal"
+                ],
+                "summary": "Purge caches",
+                "parameters": [
+                    {
+                        "description": "Entries to purge",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PurgeCacheRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PurgeCacheResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/forms/{id}/notifications": {
+            "get": {
+                "description": "Returns the title, owner and notification settings of a form. Not routed by the gateway.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "internal"
+                ],
+                "summary": "Get the notification settings of a form",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.NotificationTarget"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/forms/{id}/questions/{qid}/files/verify": {
+            "post": {
+                "security": [
+                    {
+                        "ServiceToken": []
+                    }
+                ],
+                "description": "Called by the response service as it creates a response, to check the file tokens of an answer and attach the uploads to the response. Requires the internal service token. Not routed to clients.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "internal"
+                ],
+                "summary": "Verify file answers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Question ID",
+                        "name": "qid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "File tokens",
+                        "name": "tokens",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.VerifyFileTokensRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.VerifyFileTokensResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/forms/{id}/responses/draft/consume": {
+            "post": {
+                "security": [
+                    {
+                        "ServiceToken": []
+                    }
+                ],
+                "description": "Returns and deletes the draft of a submitted response. Requires the internal service token. Not routed by the gateway.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "internal"
+                ],
+                "summary": "Consume a response draft",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Draft owner",
+                        "name": "owner",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.ConsumeDraftRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ResponseDraft"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/forms/{id}/responses/receipt": {
+            "post": {
+                "description": "Returns the PDF receipt of a response: the title of the form, every question with the answer of the response or the names of the files uploaded to it, the submission time, the response ID and a verification hash, also sent in the X-Receipt-Hash header. Titles are translated to the locale when the form has it. Receipts are stored once rendered and served again for the same response, revision and locale. With user_id, the user must be allowed to view the form. Not routed by the gateway.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/pdf"
+                ],
+                "tags": [
+                    "internal"
+                ],
+                "summary": "Render the receipt of a response",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Response",
+                        "name": "response",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.ReceiptRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        },
+                        "headers": {
+                            "X-Receipt-Hash": {
+                                "type": "string",
+                                "description": "Verification hash printed on the receipt"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/forms/{id}/responses/test/authorize": {
+            "post": {
+                "description": "Answers 204 when the user is the owner or a collaborator of the published form, and may submit test responses to it. Test responses are flagged, left out of quotas, analytics, exports and notifications. Not routed by the gateway.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "internal"
+                ],
+                "summary": "Authorize a test submission",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User submitting",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.TestSubmissionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/forms/{id}/responses/validate": {
+            "post": {
+                "description": "Checks the answers of a response, keyed by question ID, against the cross-field rules of the form. Broken rules are listed with their messages in the locale of the respondent. Rules reading unanswered questions hold. Not routed by the gateway.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "internal"
+                ],
+                "summary": "Check a response against the rules of a form",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Answers of the response",
+                        "name": "response",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.CheckResponseRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.CheckResponseResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/forms/{id}/throttling": {
+            "get": {
+                "description": "Returns whether the form is throttled and its throttling thresholds. Not routed by the gateway.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "internal"
+                ],
+                "summary": "Get the throttling of a form",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.ProtectionStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Throttles or releases the form. Changes publish form.throttle.engaged or form.throttle.released with the rates given, which the owner is notified of. Not routed by the gateway.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "internal"
+                ],
+                "summary": "Throttle or release a form",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Throttling and the rates it was decided on",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.SetThrottledRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.ProtectionStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/forms/{id}/usage/responses": {
+            "post": {
+                "description": "Counts the response against the monthly response quota of the organization of the form, answering 402 with code upgrade_required once the quota is reached. A response admitted again is not counted twice. Not routed to clients.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "internal"
+                ],
+                "summary": "Admit a response",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Response to admit",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.AdmitResponseRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "402": {
+                        "description": "Payment Required",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.QuotaErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/privacy/erasure": {
+            "post": {
+                "security": [
+                    {
+                        "ServiceToken": []
+                    }
+                ],
+                "description": "Deletes or anonymizes the forms the user owns, as the erasure policy says, and removes their collaborations and response drafts. Returns the number of records removed per kind. Erasing a user again removes nothing. Requires the internal service token. Not routed by the gateway.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "internal"
+                ],
+                "summary": "Erase the data of a user",
+                "parameters": [
+                    {
+                        "description": "Erasure request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.PrivacyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.PrivacyResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/privacy/export": {
+            "post": {
+                "security": [
+                    {
+                        "ServiceToken": []
+                    }
+                ],
+                "description": "Stores a JSON archive of the forms the user owns, their collaborations and response drafts, and the submissions passed in, and returns a time-limited link to download it. Requires the internal service token. Not routed by the gateway.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "internal"
+                ],
+                "summary": "Export the data of a user",
+                "parameters": [
+                    {
+                        "description": "Export request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.PrivacyExportRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.PrivacyResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/stats": {
+            "get": {
+                "description": "Reports form totals to other services. Not routed by the gateway.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "internal"
+                ],
+                "summary": "Form totals",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StatsResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/users/{userId}/limits": {
+            "get": {
+                "description": "The quotas of the plan of the organization the request acts in, or the personal organization of the user, with its usage this month and the bounds on its exports. Any member of the organization may be reported them. Not routed to clients.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "internal"
+                ],
+                "summary": "Get the limits of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.LimitsReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "FunnelReport": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "description": "Days are the days of the range, oldest first, those without sessions\nincluded",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analytics.FunnelDay"
+                    }
+                },
+                "form_id": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string",
+                    "example": "2026-09-17"
+                },
+                "generated_at": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string",
+                    "example": "2026-10-16"
+                },
+                "totals": {
+                    "description": "Totals counts the sessions of every day of the range. Its rates are\nthose of the complete days, as the sessions of today may still\nconvert, unless today is the only day of the range.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/FunnelTotals"
+                        }
+                    ]
+                }
+            }
+        },
+        "FunnelTotals": {
+            "type": "object",
+            "properties": {
+                "completion_rate": {
+                    "type": "number",
+                    "example": 0.7593
+                },
+                "conversion_rate": {
+                    "type": "number",
+                    "example": 0.3417
+                },
+                "start_rate": {
+                    "type": "number",
+                    "example": 0.45
+                },
+                "starts": {
+                    "type": "integer",
+                    "example": 540
+                },
+                "submissions": {
+                    "type": "integer",
+                    "example": 410
+                },
+                "views": {
+                    "type": "integer",
+                    "example": 1200
+                }
+            }
+        },
+        "QuestionTimingStats": {
+            "type": "object",
+            "properties": {
+                "drop_off_rate": {
+                    "type": "number",
+                    "example": 0.0092
+                },
+                "dropped_off": {
+                    "description": "DroppedOff counts the abandoned drafts whose last answered question\nit is, and DropOffRate their share of Reached",
+                    "type": "integer",
+                    "example": 12
+                },
+                "mean_revisits": {
+                    "description": "MeanRevisits is the average number of times respondents returned to\nthe question",
+                    "type": "number",
+                    "example": 0.3
+                },
+                "median_ms": {
+                    "type": "number",
+                    "example": 8400
+                },
+                "p90_ms": {
+                    "type": "number",
+                    "example": 21500
+                },
+                "question_id": {
+                    "type": "string"
+                },
+                "reached": {
+                    "description": "Reached counts the respondents who answered the question or a later\none: the responses, and the abandoned drafts stopping there or later",
+                    "type": "integer",
+                    "example": 1300
+                },
+                "samples": {
+                    "description": "Samples counts the timed responses which timed the question",
+                    "type": "integer",
+                    "example": 1170
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "TimingReport": {
+            "type": "object",
+            "properties": {
+                "abandoned_after": {
+                    "type": "string",
+                    "example": "24h0m0s"
+                },
+                "abandoned_drafts": {
+                    "description": "AbandonedDrafts counts the drafts left idle for longer than the\nabandonment threshold, whose last answered question they stopped at",
+                    "type": "integer",
+                    "example": 85
+                },
+                "form_id": {
+                    "type": "string"
+                },
+                "generated_at": {
+                    "type": "string"
+                },
+                "questions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/QuestionTimingStats"
+                    }
+                },
+                "responses": {
+                    "description": "Responses counts the responses of the form, and Timed the latest of\nthem whose timings were sampled",
+                    "type": "integer",
+                    "example": 1250
+                },
+                "timed": {
+                    "type": "integer",
+                    "example": 1180
+                }
+            }
+        },
+        "analytics.AnswerCount": {
+            "type": "object",
+            "properties": {
+                "answer": {
+                    "type": "object"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "percentage": {
+                    "description": "Percentage is of the responses of the group. The percentages of a\nmultiple choice question may add up to more than 100.",
+                    "type": "number",
+                    "example": 42.5
+                }
+            }
+        },
+        "analytics.Filter": {
+            "type": "object",
+            "properties": {
+                "operator": {
+                    "type": "string",
+                    "enum": [
+                        "eq",
+                        "neq",
+                        "in",
+                        "not_in",
+                        "contains",
+                        "gt",
+                        "gte",
+                        "lt",
+                        "lte"
+                    ]
+                },
+                "question": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "object"
+                }
+            }
+        },
+        "analytics.FunnelDay": {
+            "type": "object",
+            "properties": {
+                "completion_rate": {
+                    "type": "number",
+                    "example": 0.7593
+                },
+                "conversion_rate": {
+                    "type": "number",
+                    "example": 0.3417
+                },
+                "date": {
+                    "type": "string",
+                    "example": "2026-10-16"
+                },
+                "partial": {
+                    "description": "Partial is set for today, whose sessions are still being counted",
+                    "type": "boolean"
+                },
+                "start_rate": {
+                    "type": "number",
+                    "example": 0.45
+                },
+                "starts": {
+                    "type": "integer",
+                    "example": 540
+                },
+                "submissions": {
+                    "type": "integer",
+                    "example": 410
+                },
+                "views": {
+                    "type": "integer",
+                    "example": 1200
+                }
+            }
+        },
+        "analytics.GroupBy": {
+            "type": "object",
+            "properties": {
+                "question": {
+                    "type": "string"
+                },
+                "time_bucket": {
+                    "type": "string",
+                    "enum": [
+                        "hour",
+                        "day",
+                        "week",
+                        "month"
+                    ]
+                }
+            }
+        },
+        "analytics.Query": {
+            "type": "object",
+            "required": [
+                "question"
+            ],
+            "properties": {
+                "filters": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analytics.Filter"
+                    }
+                },
+                "from": {
+                    "description": "From and To bound the submission time of the responses counted, To\nexcluded",
+                    "type": "string",
+                    "example": "2024-03-01T00:00:00Z"
+                },
+                "group_by": {
+                    "$ref": "#/definitions/analytics.GroupBy"
+                },
+                "question": {
+                    "description": "Question is the ID or key of the question whose answers are counted",
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string",
+                    "example": "2024-04-01T00:00:00Z"
+                }
+            }
+        },
+        "analytics.QueryGroup": {
+            "type": "object",
+            "properties": {
+                "answers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analytics.AnswerCount"
+                    }
+                },
+                "key": {
+                    "description": "Key is the answer to the group-by question or the start of the time\nbucket; null without group_by and for the responses not answering the\ngroup-by question",
+                    "type": "object"
+                },
+                "responses": {
+                    "type": "integer"
+                }
+            }
+        },
+        "analytics.QueryResult": {
+            "type": "object",
+            "properties": {
+                "form_id": {
+                    "type": "string"
+                },
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analytics.QueryGroup"
+                    }
+                },
+                "question": {
+                    "type": "string"
+                },
+                "responses": {
+                    "description": "Responses is the number of responses matching the filters that\nanswered the question",
+                    "type": "integer"
+                },
+                "truncated": {
+                    "description": "Truncated is set when the result was cut at MaxQueryGroups rows",
+                    "type": "boolean"
+                }
+            }
+        },
+        "handlers.AdmitResponseRequest": {
+            "type": "object",
+            "required": [
+                "response_id"
+            ],
+            "properties": {
+                "response_id": {
+                    "description": "ResponseID identifies the response; admitting it again does not\ncount it twice",
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "handlers.CSVTooLargeResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "25000 rows exceed the CSV limit of 10000"
+                },
+                "export": {
+                    "description": "Export is the asynchronous export API to use instead",
+                    "type": "string",
+                    "example": "/api/v1/forms/{id}/exports"
+                }
+            }
+        },
+        "handlers.CollaboratorListResponse": {
+            "type": "object",
+            "properties": {
+                "collaborators": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Collaborator"
+                    }
+                }
+            }
+        },
+        "handlers.DraftSavedResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "form_id": {
+                    "type": "string"
+                },
+                "size_bytes": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.EmbedDefinitionResponse": {
+            "type": "object",
+            "properties": {
+                "embed": {
+                    "$ref": "#/definitions/handlers.EmbedPolicy"
+                },
+                "form": {
+                    "$ref": "#/definitions/models.Form"
+                },
+                "questions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Question"
+                    }
+                },
+                "response_policy": {
+                    "$ref": "#/definitions/models.ResponsePolicy"
+                },
+                "shuffle_seed": {
+                    "description": "ShuffleSeed seeds the shuffle of the questions with randomized\noptions for the respondent of the X-Respondent-Token header",
+                    "type": "integer"
+                },
+                "spam_protection": {
+                    "$ref": "#/definitions/models.SpamChallenge"
+                },
+                "view_token": {
+                    "description": "ViewToken is the token the funnel beacons of the respondent of the\nX-Respondent-Token header carry",
+                    "type": "string",
+                    "example": "20261016.6f1c2d9e8a7b4c3d2e1f0a9b8c7d6e5f.e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+                }
+            }
+        },
+        "handlers.EmbedPolicy": {
+            "type": "object",
+            "properties": {
+                "allowed_origins": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "https://www.acme.com"
+                    ]
+                },
+                "frame_ancestors": {
+                    "description": "FrameAncestors is the Content-Security-Policy directive pages framing\nthe form should be served with",
+                    "type": "string",
+                    "example": "frame-ancestors 'self' https://www.acme.com"
+                },
+                "submit_url": {
+                    "type": "string",
+                    "example": "https://api.example.com/responses"
+                }
+            }
+        },
+        "handlers.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "invalid form ID"
+                }
+            }
+        },
+        "handlers.FormResponse": {
+            "type": "object",
+            "properties": {
+                "form": {
+                    "$ref": "#/definitions/models.Form"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Form updated successfully"
+                }
+            }
+        },
+        "handlers.GetFormResponse": {
+            "type": "object",
+            "properties": {
+                "form": {
+                    "$ref": "#/definitions/models.Form"
+                },
+                "response_policy": {
+                    "$ref": "#/definitions/models.ResponsePolicy"
+                },
+                "test_mode_available": {
+                    "description": "TestModeAvailable is set when the caller may submit test responses\nto the form, with the X-Test-Submission header",
+                    "type": "boolean"
+                }
+            }
+        },
+        "handlers.HealthResponse": {
+            "type": "object",
+            "properties": {
+                "architecture": {
+                    "type": "string"
+                },
+                "service": {
+                    "type": "string",
+                    "example": "form-service"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "healthy"
+                },
+                "timestamp": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string",
+                    "example": "1.0.0"
+                }
+            }
+        },
+        "handlers.LibraryInsertResponse": {
+            "type": "object",
+            "properties": {
+                "questions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Question"
+                    }
+                }
+            }
+        },
+        "handlers.MemberListResponse": {
+            "type": "object",
+            "properties": {
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.OrganizationMember"
+                    }
+                }
+            }
+        },
+        "handlers.MessageResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Form deleted successfully"
+                }
+            }
+        },
+        "handlers.NotificationChannelListResponse": {
+            "type": "object",
+            "properties": {
+                "channels": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.NotificationChannel"
+                    }
+                }
+            }
+        },
+        "handlers.OrganizationListResponse": {
+            "type": "object",
+            "properties": {
+                "organizations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Organization"
+                    }
+                }
+            }
+        },
+        "handlers.PreviewTokenListResponse": {
+            "type": "object",
+            "properties": {
+                "tokens": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PreviewToken"
+                    }
+                }
+            }
+        },
+        "handlers.PublishFormResponse": {
+            "type": "object",
+            "properties": {
+                "form": {
+                    "$ref": "#/definitions/models.Form"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Form published successfully"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "handlers.PurgeCacheRequest": {
+            "type": "object",
+            "properties": {
+                "pattern": {
+                    "description": "Pattern matches the IDs of the forms whose entries are purged, where *\nmatches any run of characters and ? any one; every entry by default",
+                    "type": "string",
+                    "example": "*"
+                }
+            }
+        },
+        "handlers.PurgeCacheResponse": {
+            "type": "object",
+            "properties": {
+                "purged": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "handlers.QuotaErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "upgrade_required"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "quota exceeded: the free plan allows 3 active forms; close a form or upgrade"
+                }
+            }
+        },
+        "handlers.ReportListResponse": {
+            "type": "object",
+            "properties": {
+                "reports": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Report"
+                    }
+                }
+            }
+        },
+        "handlers.StatsResponse": {
+            "type": "object",
+            "properties": {
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.TransferListResponse": {
+            "type": "object",
+            "properties": {
+                "transfers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FormTransfer"
+                    }
+                }
+            }
+        },
+        "handlers.VerifyFileTokensResponse": {
+            "type": "object",
+            "properties": {
+                "files": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FileUpload"
+                    }
+                },
+                "valid": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "health.Liveness": {
+            "type": "object",
+            "properties": {
+                "alive": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "health.Report": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/health.Result"
+                    }
+                },
+                "ready": {
+                    "type": "boolean"
+                },
+                "timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "health.Result": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "description": "Detail describes the dependency beyond up or down, e.g. the state of\nthe database connection pool",
+                    "type": "object"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "latency_ms": {
+                    "type": "integer"
+                },
+                "status": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/health.Status"
+                        }
+                    ],
+                    "example": "up"
+                }
+            }
+        },
+        "health.Status": {
+            "type": "string",
+            "enum": [
+                "up",
+                "down"
+            ],
+            "x-enum-varnames": [
+                "StatusUp",
+                "StatusDown"
+            ]
+        },
+        "models.ActivityAction": {
+            "type": "string",
+            "enum": [
+                "form.created",
+                "form.updated",
+                "form.published",
+                "form.translation_updated",
+                "question.added",
+                "question.updated",
+                "question.deleted",
+                "questions.reordered",
+                "form.merged"
+            ],
+            "x-enum-varnames": [
+                "ActivityFormCreated",
+                "ActivityFormUpdated",
+                "ActivityFormPublished",
+                "ActivityTranslationUpdated",
+                "ActivityQuestionAdded",
+                "ActivityQuestionUpdated",
+                "ActivityQuestionDeleted",
+                "ActivityQuestionsReordered",
+                "ActivityFormMerged"
+            ]
+        },
+        "models.CaptchaProvider": {
+            "type": "string",
+            "enum": [
+                "recaptcha",
+                "hcaptcha",
+                "turnstile"
+            ],
+            "x-enum-varnames": [
+                "CaptchaProviderRecaptcha",
+                "CaptchaProviderHCaptcha",
+                "CaptchaProviderTurnstile"
+            ]
+        },
+        "models.ChannelType": {
+            "type": "string",
+            "enum": [
+                "slack",
+                "teams"
+            ],
+            "x-enum-varnames": [
+                "ChannelTypeSlack",
+                "ChannelTypeTeams"
+            ]
+        },
+        "models.CleanupJob": {
+            "type": "object",
+            "properties": {
+                "archived": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "criteria": {
+                    "type": "object"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "matched": {
+                    "description": "Matched counts the forms walked that matched the criteria, Archived\nthose archived and Failed those that could not be checked or archived",
+                    "type": "integer"
+                },
+                "requested_by": {
+                    "description": "RequestedBy is the admin who started the cleanup",
+                    "type": "string"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.CleanupJobStatus"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CleanupJobStatus": {
+            "type": "string",
+            "enum": [
+                "queued",
+                "running",
+                "completed",
+                "cancelled",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "CleanupJobQueued",
+                "CleanupJobRunning",
+                "CleanupJobCompleted",
+                "CleanupJobCancelled",
+                "CleanupJobFailed"
+            ]
+        },
+        "models.Collaborator": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "form": {
+                    "description": "Relationships",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Form"
+                        }
+                    ]
+                },
+                "form_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "invited_at": {
+                    "type": "string"
+                },
+                "invited_by": {
+                    "type": "string"
+                },
+                "joined_at": {
+                    "type": "string"
+                },
+                "role": {
+                    "$ref": "#/definitions/models.CollaboratorRole"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.CollaboratorStatus"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CollaboratorRole": {
+            "type": "string",
+            "enum": [
+                "viewer",
+                "editor",
+                "manager",
+                "owner"
+            ],
+            "x-enum-varnames": [
+                "CollaboratorRoleViewer",
+                "CollaboratorRoleEditor",
+                "CollaboratorRoleManager",
+                "CollaboratorRoleOwner"
+            ]
+        },
+        "models.CollaboratorStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "active",
+                "inactive"
+            ],
+            "x-enum-varnames": [
+                "CollaboratorStatusPending",
+                "CollaboratorStatusActive",
+                "CollaboratorStatusInactive"
+            ]
+        },
+        "models.ExportFormat": {
+            "type": "string",
+            "enum": [
+                "csv",
+                "xlsx"
+            ],
+            "x-enum-varnames": [
+                "ExportFormatCSV",
+                "ExportFormatXLSX"
+            ]
+        },
+        "models.ExportJob": {
+            "type": "object",
+            "properties": {
+                "bytes_written": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "download_url": {
+                    "description": "DownloadURL is the endpoint signing download links to the completed\nexport, set when the job is read",
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "files_skipped": {
+                    "type": "integer"
+                },
+                "files_written": {
+                    "type": "integer"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "form_id": {
+                    "type": "string"
+                },
+                "format": {
+                    "$ref": "#/definitions/models.ExportFormat"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "include_files": {
+                    "type": "boolean"
+                },
+                "include_labels": {
+                    "description": "IncludeLabels adds a second header row of question titles under the\nrow of question keys",
+                    "type": "boolean"
+                },
+                "include_presented_order": {
+                    "description": "IncludePresentedOrder adds, after each question with randomized\noptions, a column of the order the options were shown in",
+                    "type": "boolean"
+                },
+                "include_test": {
+                    "description": "IncludeTest exports the test submissions of owners and collaborators\ntoo, flagged in a test column",
+                    "type": "boolean"
+                },
+                "include_timings": {
+                    "description": "IncludeTimings adds, after each question, the seconds it held focus\nand the times the respondent returned to it, from the timing beacons\nof the client",
+                    "type": "boolean"
+                },
+                "organization_id": {
+                    "type": "string"
+                },
+                "priority": {
+                    "$ref": "#/definitions/models.ExportPriority"
+                },
+                "queue_position": {
+                    "description": "QueuePosition is the place of a queued job among the jobs of its\norganization waiting to run, from 1, set when the job is read",
+                    "type": "integer"
+                },
+                "requested_by": {
+                    "type": "string"
+                },
+                "responses": {
+                    "description": "Progress: the responses exported, the bytes of the export written to\nstorage, the files archived and those replaced by a placeholder\nbecause they are missing or quarantined",
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.ExportJobStatus"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ExportJobStatus": {
+            "type": "string",
+            "enum": [
+                "queued",
+                "running",
+                "completed",
+                "failed",
+                "cancelled"
+            ],
+            "x-enum-varnames": [
+                "ExportJobQueued",
+                "ExportJobRunning",
+                "ExportJobCompleted",
+                "ExportJobFailed",
+                "ExportJobCancelled"
+            ]
+        },
+        "models.ExportPriority": {
+            "type": "string",
+            "enum": [
+                "normal",
+                "low"
+            ],
+            "x-enum-varnames": [
+                "ExportPriorityNormal",
+                "ExportPriorityLow"
+            ]
+        },
+        "models.FileScanStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "clean",
+                "infected",
+                "unscannable"
+            ],
+            "x-enum-varnames": [
+                "FileScanStatusPending",
+                "FileScanStatusClean",
+                "FileScanStatusInfected",
+                "FileScanStatusUnscannable"
+            ]
+        },
+        "models.FileUpload": {
+            "type": "object",
+            "properties": {
+                "attached_at": {
+                    "type": "string"
+                },
+                "content_type": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "file_name": {
+                    "type": "string"
+                },
+                "form_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "object_key": {
+                    "type": "string"
+                },
+                "quarantined": {
+                    "type": "boolean"
+                },
+                "question_id": {
+                    "type": "string"
+                },
+                "response_id": {
+                    "type": "string"
+                },
+                "scan_signature": {
+                    "type": "string"
+                },
+                "scan_status": {
+                    "description": "Antivirus scan results",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.FileScanStatus"
+                        }
+                    ]
+                },
+                "scanned_at": {
+                    "type": "string"
+                },
+                "size_bytes": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.FileUploadStatus"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.FileUploadStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "attached"
+            ],
+            "x-enum-varnames": [
+                "FileUploadStatusPending",
+                "FileUploadStatusAttached"
+            ]
+        },
+        "models.Form": {
+            "type": "object",
+            "properties": {
+                "collaborator_count": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "default_locale": {
+                    "description": "Internationalization: the fields above are in DefaultLocale and\nTranslations holds a TranslationBundle per additional BCP-47 locale",
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "organization_id": {
+                    "description": "OrganizationID is the organization the form belongs to",
+                    "type": "string"
+                },
+                "question_count": {
+                    "description": "Computed fields (not stored in database)",
+                    "type": "integer"
+                },
+                "response_count": {
+                    "type": "integer"
+                },
+                "retention_days": {
+                    "description": "RetentionDays is how long the responses to the form are kept before\nthe response service purges them, nil to keep them forever",
+                    "type": "integer"
+                },
+                "rules": {
+                    "description": "Rules are the cross-field validation rules of the form; see FormRule",
+                    "type": "array",
+                    "items": {
+                        "type": "object"
+                    }
+                },
+                "settings": {
+                    "type": "object"
+                },
+                "slug": {
+                    "description": "Slug names the form in public URLs, unique within its organization",
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.FormStatus"
+                },
+                "throttled_at": {
+                    "description": "ThrottledAt is when the submissions to the form were throttled for\narriving far faster than usual, nil while they are not. Saving the form\nnever writes it; see FormRepository.SetThrottled.",
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "translations": {
+                    "type": "object"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "Version counts the changes to the definition of the form, including\nits questions. Builders merge their edits against the version they\nstarted from; see FormPatchOperation.",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.FormActivity": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "enum": [
+                        "form.created",
+                        "form.updated",
+                        "form.published",
+                        "form.translation_updated",
+                        "question.added",
+                        "question.updated",
+                        "question.deleted",
+                        "questions.reordered",
+                        "form.merged"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ActivityAction"
+                        }
+                    ]
+                },
+                "actor_id": {
+                    "type": "string"
+                },
+                "changes": {
+                    "description": "Changes names the fields that changed, for updates",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "title",
+                        "settings"
+                    ]
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "form_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "target_id": {
+                    "type": "string"
+                },
+                "target_type": {
+                    "description": "TargetType is form or question, TargetID the ID of the form or the\nquestion",
+                    "type": "string",
+                    "enum": [
+                        "form",
+                        "question"
+                    ]
+                }
+            }
+        },
+        "models.FormPatchOperation": {
+            "type": "object",
+            "required": [
+                "op",
+                "path"
+            ],
+            "properties": {
+                "base": {
+                    "type": "object"
+                },
+                "op": {
+                    "type": "string",
+                    "enum": [
+                        "add",
+                        "remove",
+                        "replace"
+                    ]
+                },
+                "path": {
+                    "type": "string",
+                    "example": "/title"
+                },
+                "value": {
+                    "type": "object"
+                }
+            }
+        },
+        "models.FormRule": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "description": "Count is how many questions of an at_least rule must be answered",
+                    "type": "integer"
+                },
+                "expression": {
+                    "description": "Expression is the condition of an expression rule",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "left": {
+                    "description": "Left, Operator and Right are the keys and operator of a comparison,\nsuch as checkout \u003e checkin",
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "operator": {
+                    "type": "string",
+                    "enum": [
+                        "==",
+                        "!=",
+                        "\u003c",
+                        "\u003c=",
+                        "\u003e",
+                        "\u003e="
+                    ]
+                },
+                "questions": {
+                    "description": "Questions maps the keys the rule reads to question IDs",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "right": {
+                    "type": "string"
+                },
+                "type": {
+                    "enum": [
+                        "comparison",
+                        "sum_equals",
+                        "at_least",
+                        "expression"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.FormRuleType"
+                        }
+                    ]
+                },
+                "value": {
+                    "description": "Value is the total of a sum_equals rule",
+                    "type": "number"
+                }
+            }
+        },
+        "models.FormRuleType": {
+            "type": "string",
+            "enum": [
+                "comparison",
+                "sum_equals",
+                "at_least",
+                "expression"
+            ],
+            "x-enum-varnames": [
+                "FormRuleComparison",
+                "FormRuleSumEquals",
+                "FormRuleAtLeast",
+                "FormRuleExpression"
+            ]
+        },
+        "models.FormSettings": {
+            "type": "object",
+            "properties": {
+                "accepting_responses": {
+                    "type": "boolean"
+                },
+                "allow_multiple_response": {
+                    "type": "boolean"
+                },
+                "collect_metadata": {
+                    "description": "CollectMetadata controls whether the IP address and user agent of\nanonymous respondents are stored. Unset means collect.",
+                    "type": "boolean"
+                },
+                "confirmation_message": {
+                    "type": "string"
+                },
+                "edit_window_minutes": {
+                    "description": "EditWindowMinutes is how long after submitting a respondent may edit\ntheir response. Zero disables editing.",
+                    "type": "integer"
+                },
+                "embed_allowed_origins": {
+                    "description": "EmbedAllowedOrigins are the sites, as scheme://host[:port], allowed to\nembed the form and submit responses to it. Empty disables embedding.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "notifications": {
+                    "description": "Notifications controls the emails sent by the notification worker of\nthe event bus when the form is published and when responses arrive",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.NotificationSettings"
+                        }
+                    ]
+                },
+                "require_sign_in": {
+                    "type": "boolean"
+                },
+                "response_mode": {
+                    "$ref": "#/definitions/models.ResponseMode"
+                },
+                "show_progress_bar": {
+                    "type": "boolean"
+                },
+                "shuffle_questions": {
+                    "type": "boolean"
+                },
+                "spam_protection": {
+                    "description": "SpamProtection is the challenge public submissions must pass. Unset\nmeans none.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.SpamProtection"
+                        }
+                    ]
+                },
+                "throttling": {
+                    "description": "Throttling tunes when a surge of submissions throttles the form",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ThrottleSettings"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.FormStatus": {
+            "type": "string",
+            "enum": [
+                "draft",
+                "published",
+                "closed"
+            ],
+            "x-enum-varnames": [
+                "FormStatusDraft",
+                "FormStatusPublished",
+                "FormStatusClosed"
+            ]
+        },
+        "models.FormTransfer": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "form_id": {
+                    "type": "string"
+                },
+                "form_title": {
+                    "description": "FormTitle is the title of the form when the transfer was requested,\nfor the target who can't see the form yet",
+                    "type": "string"
+                },
+                "from_organization_id": {
+                    "type": "string"
+                },
+                "from_user_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "requested_by": {
+                    "type": "string"
+                },
+                "resolved_at": {
+                    "type": "string"
+                },
+                "resolved_by": {
+                    "description": "ResolvedBy is the user who accepted or cancelled the transfer",
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.TransferStatus"
+                },
+                "to_organization_id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "to_user_id": {
+                    "description": "Exactly one of ToUserID and ToOrganizationID is set",
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.LibraryQuestion": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "options": {
+                    "type": "object"
+                },
+                "organization_id": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/models.QuestionType"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "validation": {
+                    "type": "object"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.LibraryQuestionUsage": {
+            "type": "object",
+            "properties": {
+                "form_id": {
+                    "type": "string"
+                },
+                "form_status": {
+                    "enum": [
+                        "draft",
+                        "published",
+                        "closed"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.FormStatus"
+                        }
+                    ]
+                },
+                "form_title": {
+                    "type": "string"
+                },
+                "library_version": {
+                    "type": "integer"
+                },
+                "question_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.NotificationChannel": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "form_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "include_answer_summary": {
+                    "description": "IncludeAnswerSummary posts the answers of responses; otherwise\nmessages never show answer values",
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "quiet_hours_end": {
+                    "type": "string"
+                },
+                "quiet_hours_start": {
+                    "description": "QuietHoursStart and QuietHoursEnd bound, as HH:MM in Timezone, the\ntime of day nothing is posted; events then are not posted later.\nThe end may be past midnight. Empty disables quiet hours.",
+                    "type": "string"
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/models.ChannelType"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "webhook_hint": {
+                    "description": "WebhookHint tells webhooks apart without revealing them",
+                    "type": "string"
+                }
+            }
+        },
+        "models.NotificationSettings": {
+            "type": "object",
+            "properties": {
+                "attach_receipt": {
+                    "description": "AttachReceipt attaches the PDF receipt of the response to the copy\nsent to respondents",
+                    "type": "boolean"
+                },
+                "notify_owner_on_response": {
+                    "description": "NotifyOwnerOnResponse emails OwnerEmail when a response arrives",
+                    "type": "boolean"
+                },
+                "owner_email": {
+                    "description": "OwnerEmail is the address owner notifications are sent to",
+                    "type": "string"
+                },
+                "reply_to": {
+                    "description": "ReplyTo is the Reply-To address of the emails sent to respondents",
+                    "type": "string"
+                },
+                "send_respondent_copy": {
+                    "description": "SendRespondentCopy emails respondents who gave an address a copy of\ntheir answers",
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.Organization": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "personal": {
+                    "description": "Personal organizations are created for each user and hold the forms\ncreated before organizations existed",
+                    "type": "boolean"
+                },
+                "plan": {
+                    "description": "Plan names the plan whose quotas apply to the organization",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.OrganizationMember": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "invited_by": {
+                    "type": "string"
+                },
+                "joined_at": {
+                    "type": "string"
+                },
+                "organization_id": {
+                    "type": "string"
+                },
+                "role": {
+                    "$ref": "#/definitions/models.OrganizationRole"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.OrganizationRole": {
+            "type": "string",
+            "enum": [
+                "member",
+                "admin",
+                "owner"
+            ],
+            "x-enum-varnames": [
+                "OrganizationRoleMember",
+                "OrganizationRoleAdmin",
+                "OrganizationRoleOwner"
+            ]
+        },
+        "models.Plan": {
+            "type": "object",
+            "properties": {
+                "max_active_forms": {
+                    "description": "MaxActiveForms bounds the forms of the organization not closed",
+                    "type": "integer"
+                },
+                "max_responses_per_month": {
+                    "description": "MaxResponsesPerMonth bounds the responses collected in a month",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PreviewToken": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "form_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "description": "RevokedAt is when the token was revoked, nil while it is not",
+                    "type": "string"
+                }
+            }
+        },
+        "models.Question": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "display": {
+                    "description": "Display holds the display settings of choice questions",
+                    "type": "object"
+                },
+                "form": {
+                    "description": "Relationships",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Form"
+                        }
+                    ]
+                },
+                "form_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "key": {
+                    "description": "Key is the stable name of the question, unique within the form, which\nresponses are projected under and exports name their columns after.\nIt is generated from the title unless given, and only changes when\nrenamed.",
+                    "type": "string",
+                    "example": "age_group"
+                },
+                "library_question_id": {
+                    "description": "LibraryQuestionID and LibraryVersion are the library question the\nquestion was copied from and its version, if any",
+                    "type": "string"
+                },
+                "library_version": {
+                    "type": "integer"
+                },
+                "options": {
+                    "type": "object"
+                },
+                "order": {
+                    "type": "integer"
+                },
+                "results_visibility": {
+                    "description": "ResultsVisibility is private unless the distribution of the answers is\npublished. Only questions of aggregatable types may be published.",
+                    "enum": [
+                        "private",
+                        "aggregate_public"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ResultsVisibility"
+                        }
+                    ]
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/models.QuestionType"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "validation": {
+                    "type": "object"
+                }
+            }
+        },
+        "models.QuestionTranslation": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "options": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                },
+                "validation_messages": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.QuestionType": {
+            "type": "string",
+            "enum": [
+                "text",
+                "textarea",
+                "number",
+                "email",
+                "select",
+                "radio",
+                "checkbox",
+                "file"
+            ],
+            "x-enum-varnames": [
+                "QuestionTypeText",
+                "QuestionTypeTextarea",
+                "QuestionTypeNumber",
+                "QuestionTypeEmail",
+                "QuestionTypeSelect",
+                "QuestionTypeRadio",
+                "QuestionTypeCheckbox",
+                "QuestionTypeFile"
+            ]
+        },
+        "models.Report": {
+            "type": "object",
+            "properties": {
+                "completion_rate": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "download_url": {
+                    "description": "DownloadURL is a short-lived link to the artifact, set when listing",
+                    "type": "string"
+                },
+                "form_id": {
+                    "type": "string"
+                },
+                "format": {
+                    "$ref": "#/definitions/models.ReportFormat"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "period_end": {
+                    "type": "string"
+                },
+                "period_start": {
+                    "type": "string"
+                },
+                "responses": {
+                    "description": "Responses is the number of responses of the period",
+                    "type": "integer"
+                },
+                "size_bytes": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ReportFormat": {
+            "type": "string",
+            "enum": [
+                "html",
+                "csv"
+            ],
+            "x-enum-varnames": [
+                "ReportFormatHTML",
+                "ReportFormatCSV"
+            ]
+        },
+        "models.ReportFrequency": {
+            "type": "string",
+            "enum": [
+                "daily",
+                "weekly"
+            ],
+            "x-enum-varnames": [
+                "ReportFrequencyDaily",
+                "ReportFrequencyWeekly"
+            ]
+        },
+        "models.ReportSchedule": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "form_id": {
+                    "type": "string"
+                },
+                "format": {
+                    "$ref": "#/definitions/models.ReportFormat"
+                },
+                "frequency": {
+                    "$ref": "#/definitions/models.ReportFrequency"
+                },
+                "include_empty": {
+                    "description": "IncludeEmpty also reports periods without responses, which are\nskipped otherwise",
+                    "type": "boolean"
+                },
+                "next_run_at": {
+                    "type": "string"
+                },
+                "recipients": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "send_at": {
+                    "description": "SendAt is the local time of day reports run at, as HH:MM",
+                    "type": "string"
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "weekday": {
+                    "description": "Weekday is the day weekly reports run on, 0 being Sunday",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ResponseDeletionJob": {
+            "type": "object",
+            "properties": {
+                "cap_overridden": {
+                    "description": "CapOverridden is set when an admin of the organization let the\ndeletion past the share of the responses of a form that can be\ndeleted at once",
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "filter": {
+                    "type": "object"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "form_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "matched": {
+                    "description": "Matched is the number of responses the dry run counted, the most the\njob deletes, and Total the responses of the form then",
+                    "type": "integer"
+                },
+                "organization_id": {
+                    "type": "string"
+                },
+                "requested_by": {
+                    "type": "string"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.ResponseDeletionStatus"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ResponseDeletionStatus": {
+            "type": "string",
+            "enum": [
+                "queued",
+                "running",
+                "completed",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "ResponseDeletionQueued",
+                "ResponseDeletionRunning",
+                "ResponseDeletionCompleted",
+                "ResponseDeletionFailed"
+            ]
+        },
+        "models.ResponseDraft": {
+            "type": "object",
+            "properties": {
+                "answers": {
+                    "type": "object"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "form_id": {
+                    "type": "string"
+                },
+                "size_bytes": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ResponseMode": {
+            "type": "string",
+            "enum": [
+                "anonymous",
+                "identified",
+                "one_per_user"
+            ],
+            "x-enum-varnames": [
+                "ResponseModeAnonymous",
+                "ResponseModeIdentified",
+                "ResponseModeOnePerUser"
+            ]
+        },
+        "models.ResponsePolicy": {
+            "type": "object",
+            "properties": {
+                "collect_metadata": {
+                    "type": "boolean"
+                },
+                "edit_window_minutes": {
+                    "type": "integer"
+                },
+                "mode": {
+                    "$ref": "#/definitions/models.ResponseMode"
+                },
+                "one_response_per_user": {
+                    "type": "boolean"
+                },
+                "requires_login": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.ResultsVisibility": {
+            "type": "string",
+            "enum": [
+                "private",
+                "aggregate_public"
+            ],
+            "x-enum-varnames": [
+                "ResultsVisibilityPrivate",
+                "ResultsVisibilityAggregatePublic"
+            ]
+        },
+        "models.RuleViolation": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "question_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "rule_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SpamChallenge": {
+            "type": "object",
+            "properties": {
+                "honeypot_field": {
+                    "description": "HoneypotField is the hidden field submitted as honeypot, which must be\nleft empty",
+                    "type": "string",
+                    "example": "website"
+                },
+                "mode": {
+                    "enum": [
+                        "captcha",
+                        "honeypot"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.SpamProtection"
+                        }
+                    ]
+                },
+                "provider": {
+                    "description": "Provider and SiteKey render the CAPTCHA widget whose token is\nsubmitted as captchaToken",
+                    "enum": [
+                        "recaptcha",
+                        "hcaptcha",
+                        "turnstile"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.CaptchaProvider"
+                        }
+                    ]
+                },
+                "site_key": {
+                    "type": "string"
+                },
+                "token": {
+                    "description": "Token is the signed issue time of the definition, submitted as\nchallengeToken",
+                    "type": "string"
+                }
+            }
+        },
+        "models.SpamProtection": {
+            "type": "string",
+            "enum": [
+                "none",
+                "captcha",
+                "honeypot"
+            ],
+            "x-enum-varnames": [
+                "SpamProtectionNone",
+                "SpamProtectionCaptcha",
+                "SpamProtectionHoneypot"
+            ]
+        },
+        "models.ThrottleSettings": {
+            "type": "object",
+            "properties": {
+                "disabled": {
+                    "type": "boolean"
+                },
+                "ip_limit_per_minute": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "min_rate_per_minute": {
+                    "type": "number",
+                    "example": 30
+                },
+                "multiplier": {
+                    "type": "number",
+                    "example": 100
+                }
+            }
+        },
+        "models.TransferStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "accepted",
+                "cancelled",
+                "expired"
+            ],
+            "x-enum-varnames": [
+                "TransferStatusPending",
+                "TransferStatusAccepted",
+                "TransferStatusCancelled",
+                "TransferStatusExpired"
+            ]
+        },
+        "service.ActivityPage": {
+            "type": "object",
+            "properties": {
+                "activities": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FormActivity"
+                    }
+                },
+                "has_more": {
+                    "type": "boolean"
+                },
+                "next_cursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.BulkDeleteRequest": {
+            "type": "object",
+            "properties": {
+                "confirmation_token": {
+                    "description": "ConfirmationToken is the token of the dry run of the same filter,\nrequired to start the deletion",
+                    "type": "string"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "override_cap": {
+                    "description": "OverrideCap lets an owner or an admin of the organization of the\nform delete a greater share of its responses than the cap",
+                    "type": "boolean"
+                },
+                "response_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "submitted_after": {
+                    "type": "string",
+                    "example": "2024-01-01T00:00:00Z"
+                },
+                "submitted_before": {
+                    "description": "SubmittedBefore and SubmittedAfter select the responses first\nsubmitted before and after them",
+                    "type": "string",
+                    "example": "2024-03-01T00:00:00Z"
+                },
+                "test_only": {
+                    "description": "TestOnly selects the responses flagged as test submissions",
+                    "type": "boolean"
+                }
+            }
+        },
+        "service.ChannelTarget": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "include_answer_summary": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "quiet_hours_end": {
+                    "type": "string"
+                },
+                "quiet_hours_start": {
+                    "type": "string"
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/models.ChannelType"
+                },
+                "webhook_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.CheckResponseRequest": {
+            "type": "object",
+            "required": [
+                "answers"
+            ],
+            "properties": {
+                "answers": {
+                    "type": "object"
+                },
+                "locale": {
+                    "type": "string",
+                    "example": "de"
+                }
+            }
+        },
+        "service.CheckResponseResult": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RuleViolation"
+                    }
+                },
+                "valid": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "service.CleanupPreview": {
+            "type": "object",
+            "properties": {
+                "matched": {
+                    "type": "integer"
+                },
+                "sample": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "service.CleanupRequest": {
+            "type": "object",
+            "properties": {
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "inactive_before": {
+                    "description": "InactiveBefore selects the forms last updated before it",
+                    "type": "string",
+                    "example": "2024-01-01T00:00:00Z"
+                },
+                "organization_id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "owner_id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "requested_by": {
+                    "description": "RequestedBy is the admin starting the cleanup, set by the gateway",
+                    "type": "string"
+                },
+                "status": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.FormStatus"
+                        }
+                    ],
+                    "example": "draft"
+                },
+                "zero_responses": {
+                    "description": "ZeroResponses selects the forms that never received a response, as\ncounted by the analytics service",
+                    "type": "boolean"
+                }
+            }
+        },
+        "service.ConsumeDraftRequest": {
+            "type": "object",
+            "properties": {
+                "respondent_token": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.CreateChannelRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "type",
+                "webhook_url"
+            ],
+            "properties": {
+                "enabled": {
+                    "description": "Enabled posts events to the channel; create it disabled to send a\ntest message first",
+                    "type": "boolean"
+                },
+                "event_types": {
+                    "description": "EventTypes are the form events posted, all of them by default",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "form.response.created"
+                    ]
+                },
+                "include_answer_summary": {
+                    "description": "IncludeAnswerSummary posts the answers of responses, which are\nredacted otherwise",
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "#feedback"
+                },
+                "quiet_hours_end": {
+                    "type": "string",
+                    "example": "07:00"
+                },
+                "quiet_hours_start": {
+                    "description": "QuietHoursStart and QuietHoursEnd, as HH:MM in Timezone, bound the\ntime of day nothing is posted",
+                    "type": "string",
+                    "example": "22:00"
+                },
+                "timezone": {
+                    "description": "Timezone is an IANA time zone, UTC by default",
+                    "type": "string",
+                    "example": "Europe/Berlin"
+                },
+                "type": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ChannelType"
+                        }
+                    ],
+                    "example": "slack"
+                },
+                "webhook_url": {
+                    "description": "WebhookURL is the incoming webhook of the channel. It is stored\nencrypted and never returned.",
+                    "type": "string",
+                    "example": "https://hooks.slack.com/services/T000/B000/XXXX"
+                }
+            }
+        },
+        "service.CreateFormRequest": {
+            "type": "object",
+            "required": [
+                "title"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 2000
+                },
+                "retention_days": {
+                    "description": "RetentionDays purges the responses this many days after they are\nsubmitted; unset keeps them forever",
+                    "type": "integer",
+                    "maximum": 3650,
+                    "minimum": 1,
+                    "example": 365
+                },
+                "settings": {
+                    "$ref": "#/definitions/models.FormSettings"
+                },
+                "slug": {
+                    "type": "string",
+                    "example": "feedback"
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 200
+                }
+            }
+        },
+        "service.CreateOrganizationRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 200,
+                    "example": "Acme Research"
+                }
+            }
+        },
+        "service.CreateUploadURLRequest": {
+            "type": "object",
+            "required": [
+                "content_type",
+                "file_name",
+                "size_bytes"
+            ],
+            "properties": {
+                "content_type": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "file_name": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "size_bytes": {
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "service.ExportDownload": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "single_use": {
+                    "description": "SingleUse links download once; the client that used them may resume the download with Range requests until they expire",
+                    "type": "boolean"
+                },
+                "url": {
+                    "description": "URL downloads the export without authentication, Range requests\nincluded; it may point at the object storage",
+                    "type": "string",
+                    "example": "/api/v1/public/forms/6f1c.../exports/0b9f.../download?token=..."
+                }
+            }
+        },
+        "service.ExportLimits": {
+            "type": "object",
+            "properties": {
+                "max_concurrent_jobs": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "max_jobs_per_organization": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "service.ExportRequest": {
+            "type": "object",
+            "properties": {
+                "format": {
+                    "enum": [
+                        "csv",
+                        "xlsx"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ExportFormat"
+                        }
+                    ],
+                    "example": "csv"
+                },
+                "include_files": {
+                    "description": "IncludeFiles bundles the files answering file questions with the\nresponses in a ZIP archive",
+                    "type": "boolean"
+                },
+                "include_labels": {
+                    "description": "IncludeLabels adds a second header row naming each column by the title\nof its question, under the first naming it by the key of the question",
+                    "type": "boolean"
+                },
+                "include_presented_order": {
+                    "description": "IncludePresentedOrder adds, after each question with randomized\noptions, a column of the option keys in the order the respondent saw\nthem",
+                    "type": "boolean"
+                },
+                "include_test": {
+                    "description": "IncludeTest exports the test submissions of owners and collaborators\ntoo, flagged in a test column",
+                    "type": "boolean"
+                },
+                "include_timings": {
+                    "description": "IncludeTimings adds, after each question, the seconds it held focus\nand the times the respondent returned to it. Timings are left out of\nexports unless asked for; responses submitted without them have\nempty timing cells.",
+                    "type": "boolean"
+                },
+                "priority": {
+                    "description": "Priority is normal unless set; low priority exports only run when no\nnormal export can run instead",
+                    "enum": [
+                        "normal",
+                        "low"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ExportPriority"
+                        }
+                    ],
+                    "example": "normal"
+                }
+            }
+        },
+        "service.FileScanStatusResponse": {
+            "type": "object",
+            "properties": {
+                "file_id": {
+                    "type": "string"
+                },
+                "quarantined": {
+                    "type": "boolean"
+                },
+                "scanned_at": {
+                    "type": "string"
+                },
+                "signature": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.FileScanStatus"
+                }
+            }
+        },
+        "service.FormChange": {
+            "type": "object",
+            "properties": {
+                "deleted": {
+                    "type": "boolean"
+                },
+                "form": {
+                    "description": "Form is the current form, omitted from tombstones",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Form"
+                        }
+                    ]
+                },
+                "id": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.FormChangesResponse": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.FormChange"
+                    }
+                },
+                "has_more": {
+                    "type": "boolean"
+                },
+                "next_cursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.FormPreview": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "description": "ExpiresAt is when the token expires",
+                    "type": "string"
+                },
+                "form": {
+                    "$ref": "#/definitions/models.Form"
+                },
+                "preview": {
+                    "description": "Preview is always true, marking the definition as a preview",
+                    "type": "boolean",
+                    "example": true
+                },
+                "questions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Question"
+                    }
+                },
+                "read_only": {
+                    "description": "ReadOnly is always true: responses to previews are rejected",
+                    "type": "boolean",
+                    "example": true
+                },
+                "updated_since_issued": {
+                    "description": "UpdatedSinceIssued reports whether the form was updated after the\ntoken was issued",
+                    "type": "boolean"
+                }
+            }
+        },
+        "service.FunnelBeacon": {
+            "type": "object",
+            "properties": {
+                "stage": {
+                    "description": "Stage is view once the form is shown, and start at the first\ninteraction with an answer",
+                    "type": "string",
+                    "enum": [
+                        "view",
+                        "start"
+                    ],
+                    "example": "view"
+                },
+                "test": {
+                    "description": "Test is set by clients testing the form, whose beacons are dropped",
+                    "type": "boolean"
+                },
+                "view_token": {
+                    "description": "ViewToken is the view_token of the definition of the form",
+                    "type": "string",
+                    "example": "20261016.6f1c2d9e8a7b4c3d2e1f0a9b8c7d6e5f.e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+                }
+            }
+        },
+        "service.InsertFromLibraryRequest": {
+            "type": "object",
+            "required": [
+                "question_ids"
+            ],
+            "properties": {
+                "position": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 1
+                },
+                "question_ids": {
+                    "type": "array",
+                    "maxItems": 50,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "service.InviteCollaboratorRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "role",
+                "user_id"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "ada@example.com"
+                },
+                "role": {
+                    "enum": [
+                        "viewer",
+                        "editor",
+                        "manager"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.CollaboratorRole"
+                        }
+                    ],
+                    "example": "editor"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "5f0c6a3e-8a44-4c1f-9d43-2b6f3f3f8c11"
+                }
+            }
+        },
+        "service.InviteMemberRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "role",
+                "user_id"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "ada@example.com"
+                },
+                "role": {
+                    "enum": [
+                        "member",
+                        "admin"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.OrganizationRole"
+                        }
+                    ],
+                    "example": "member"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "5f0c6a3e-8a44-4c1f-9d43-2b6f3f3f8c11"
+                }
+            }
+        },
+        "service.IssuePreviewTokenRequest": {
+            "type": "object",
+            "properties": {
+                "expires_in": {
+                    "description": "ExpiresIn is how long the token stays valid, in seconds",
+                    "type": "integer",
+                    "minimum": 60,
+                    "example": 86400
+                }
+            }
+        },
+        "service.IssuedPreviewToken": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "form_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "preview_url": {
+                    "description": "PreviewURL is the path of the preview, relative to the API Gateway",
+                    "type": "string",
+                    "example": "/api/v1/public/forms/preview?token=..."
+                },
+                "revoked_at": {
+                    "description": "RevokedAt is when the token was revoked, nil while it is not",
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.LibraryQuestionList": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "questions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.LibraryQuestion"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.LibraryQuestionRequest": {
+            "type": "object",
+            "required": [
+                "title",
+                "type"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "options": {},
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "demographics"
+                    ]
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 500,
+                    "example": "What is your age group?"
+                },
+                "type": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.QuestionType"
+                        }
+                    ],
+                    "example": "radio"
+                },
+                "validation": {}
+            }
+        },
+        "service.LibraryUsages": {
+            "type": "object",
+            "properties": {
+                "library_question_id": {
+                    "type": "string"
+                },
+                "usages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.LibraryQuestionUsage"
+                    }
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.LimitsReport": {
+            "type": "object",
+            "properties": {
+                "active_forms": {
+                    "description": "ActiveForms are the forms not closed, counted against\nplan.max_active_forms",
+                    "type": "integer"
+                },
+                "exports": {
+                    "$ref": "#/definitions/service.ExportLimits"
+                },
+                "forms_created": {
+                    "type": "integer"
+                },
+                "month": {
+                    "type": "string"
+                },
+                "organization_id": {
+                    "type": "string"
+                },
+                "plan": {
+                    "$ref": "#/definitions/models.Plan"
+                },
+                "reconciled_at": {
+                    "description": "ReconciledAt is when the usage was last checked against the source of\ntruth",
+                    "type": "string"
+                },
+                "responses": {
+                    "type": "integer"
+                },
+                "storage_bytes": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.MergeConflict": {
+            "type": "object",
+            "properties": {
+                "base": {
+                    "type": "object"
+                },
+                "client": {
+                    "type": "object"
+                },
+                "path": {
+                    "type": "string",
+                    "example": "/title"
+                },
+                "reason": {
+                    "type": "string",
+                    "enum": [
+                        "edited",
+                        "deleted",
+                        "reordered",
+                        "exists"
+                    ]
+                },
+                "server": {
+                    "type": "object"
+                }
+            }
+        },
+        "service.MergeFormRequest": {
+            "type": "object",
+            "required": [
+                "base_version",
+                "changes"
+            ],
+            "properties": {
+                "base_version": {
+                    "description": "BaseVersion is the version of the form the changes were made on",
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 7
                 },
-                "logic": {
-                    "type": "string",
-                    "enum": [
-                        "AND",
-                        "OR"
-                    ],
-                    "example": "AND"
-                },
-                "showIf": {
+                "changes": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/dto.ConditionDTO"
+                        "$ref": "#/definitions/models.FormPatchOperation"
                     }
                 }
             }
         },
-        "dto.CreateFormRequestDTO": {
+        "service.MergeFormResult": {
             "type": "object",
-            "required": [
-                "questions",
-                "title"
-            ],
             "properties": {
-                "allowMultiple": {
-                    "type": "boolean",
-                    "example": false
-                },
-                "category": {
-                    "type": "string",
-                    "maxLength": 100,
-                    "example": "feedback"
-                },
-                "description": {
-                    "type": "string",
-                    "maxLength": 1000,
-                    "example": "Please provide your feedback about our service"
-                },
-                "expiresAt": {
-                    "type": "string",
-                    "example": "2024-12-31T23:59:59Z"
-                },
-                "isAnonymous": {
-                    "type": "boolean",
-                    "example": false
-                },
-                "isPublic": {
-                    "type": "boolean",
-                    "example": true
+                "applied": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FormPatchOperation"
+                    }
                 },
-                "questions": {
+                "conflicts": {
                     "type": "array",
-                    "minItems": 1,
                     "items": {
-                        "$ref": "#/definitions/dto.CreateQuestionRequestDTO"
+                        "$ref": "#/definitions/service.MergeConflict"
                     }
                 },
-                "settings": {
-                    "$ref": "#/definitions/dto.FormSettingsDTO"
+                "form": {
+                    "$ref": "#/definitions/models.Form"
                 },
-                "tags": {
+                "questions": {
                     "type": "array",
-                    "maxItems": 10,
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/models.Question"
                     }
                 },
-                "title": {
-                    "type": "string",
-                    "maxLength": 255,
-                    "minLength": 1,
-                    "example": "Customer Feedback Form"
+                "version": {
+                    "type": "integer",
+                    "example": 8
                 }
             }
         },
-        "dto.CreateQuestionRequestDTO": {
+        "service.NotificationTarget": {
             "type": "object",
-            "required": [
-                "label",
-                "type"
-            ],
             "properties": {
-                "conditional": {
-                    "$ref": "#/definitions/dto.ConditionalLogicDTO"
-                },
-                "description": {
-                    "type": "string",
-                    "maxLength": 1000,
-                    "example": "Please enter your full name"
-                },
-                "label": {
-                    "type": "string",
-                    "maxLength": 500,
-                    "minLength": 1,
-                    "example": "What is your name?"
-                },
-                "metadata": {
-                    "type": "object",
-                    "additionalProperties": true
-                },
-                "options": {
+                "channels": {
+                    "description": "Channels are the Slack and Teams channels of the form, with their\nwebhook URLs opened",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/dto.QuestionOptionDTO"
+                        "$ref": "#/definitions/service.ChannelTarget"
                     }
                 },
-                "order": {
-                    "type": "integer",
-                    "minimum": 0,
-                    "example": 1
+                "form_id": {
+                    "type": "string"
                 },
-                "required": {
-                    "type": "boolean",
-                    "example": true
+                "notifications": {
+                    "$ref": "#/definitions/models.NotificationSettings"
                 },
-                "type": {
-                    "type": "string",
-                    "enum": [
-                        "text",
-                        "textarea",
-                        "number",
-                        "email",
-                        "date",
-                        "checkbox",
-                        "radio",
-                        "select",
-                        "file"
-                    ],
-                    "example": "text"
+                "owner_id": {
+                    "type": "string"
                 },
-                "validation": {
-                    "$ref": "#/definitions/dto.QuestionValidationDTO"
+                "status": {
+                    "$ref": "#/definitions/models.FormStatus"
+                },
+                "title": {
+                    "type": "string"
                 }
             }
         },
-        "dto.DependencyStatusDTO": {
+        "service.PaginatedFormsResponse": {
             "type": "object",
             "properties": {
-                "error": {
-                    "type": "string"
+                "forms": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Form"
+                    }
                 },
-                "lastChecked": {
-                    "type": "string",
-                    "example": "2024-01-01T12:00:00Z"
+                "limit": {
+                    "type": "integer"
                 },
-                "responseTimeMs": {
-                    "type": "integer",
-                    "example": 25
+                "page": {
+                    "type": "integer"
                 },
-                "status": {
-                    "type": "string",
-                    "example": "healthy"
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
                 }
             }
         },
-        "dto.ErrorDetail": {
+        "service.PrivacyExportRequest": {
             "type": "object",
+            "required": [
+                "request_id",
+                "user_id"
+            ],
             "properties": {
-                "code": {
+                "email": {
                     "type": "string"
                 },
-                "details": {},
-                "fields": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "type": "string"
-                    }
-                },
-                "message": {
+                "request_id": {
+                    "description": "RequestID is the ID of the request at the gateway",
                     "type": "string"
                 },
-                "metadata": {
-                    "type": "object",
-                    "additionalProperties": true
+                "submissions": {
+                    "description": "Submissions are the responses of the user held by the response store,\narchived as they are",
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        }
+                    }
                 },
-                "path": {
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.PrivacyRequest": {
+            "type": "object",
+            "required": [
+                "request_id",
+                "user_id"
+            ],
+            "properties": {
+                "email": {
                     "type": "string"
                 },
-                "requestId": {
+                "request_id": {
+                    "description": "RequestID is the ID of the request at the gateway",
                     "type": "string"
                 },
-                "timestamp": {
+                "user_id": {
                     "type": "string"
                 }
             }
         },
-        "dto.ErrorResponse": {
+        "service.PrivacyResult": {
             "type": "object",
             "properties": {
-                "correlationId": {
-                    "type": "string"
-                },
-                "error": {
-                    "$ref": "#/definitions/dto.ErrorDetail"
+                "counts": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer",
+                        "format": "int64"
+                    }
                 },
-                "message": {
+                "download_url": {
                     "type": "string"
                 },
-                "success": {
-                    "type": "boolean"
-                },
-                "timestamp": {
+                "expires_at": {
                     "type": "string"
                 },
-                "version": {
+                "request_id": {
                     "type": "string"
                 }
             }
         },
-        "dto.FormResponseDTO": {
+        "service.PropagateResult": {
             "type": "object",
             "properties": {
-                "allowMultiple": {
-                    "type": "boolean",
-                    "example": false
-                },
-                "category": {
-                    "type": "string",
-                    "example": "feedback"
-                },
-                "createdAt": {
-                    "type": "string",
-                    "example": "2024-01-01T12:00:00Z"
-                },
-                "createdBy": {
-                    "$ref": "#/definitions/dto.UserInfoDTO"
-                },
-                "description": {
-                    "type": "string",
-                    "example": "Please provide your feedback"
-                },
-                "expiresAt": {
-                    "type": "string",
-                    "example": "2024-12-31T23:59:59Z"
-                },
-                "id": {
-                    "type": "string",
-                    "example": "f123e4567-e89b-12d3-a456-426614174000"
-                },
-                "isAnonymous": {
-                    "type": "boolean",
-                    "example": false
-                },
-                "isPublic": {
-                    "type": "boolean",
-                    "example": true
-                },
-                "publishedAt": {
-                    "type": "string",
-                    "example": "2024-01-01T12:00:00Z"
-                },
-                "questions": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/dto.QuestionResponseDTO"
-                    }
-                },
-                "settings": {
-                    "$ref": "#/definitions/dto.FormSettingsDTO"
-                },
-                "statistics": {
-                    "$ref": "#/definitions/dto.FormStatisticsDTO"
+                "library_question_id": {
+                    "type": "string"
                 },
-                "status": {
-                    "type": "string",
-                    "example": "published"
+                "skipped": {
+                    "type": "integer"
                 },
-                "tags": {
+                "updated_forms": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
-                "title": {
-                    "type": "string",
-                    "example": "Customer Feedback Form"
-                },
-                "updatedAt": {
-                    "type": "string",
-                    "example": "2024-01-01T12:00:00Z"
+                "version": {
+                    "type": "integer"
                 }
             }
         },
-        "dto.FormSettingsDTO": {
+        "service.ProtectionStatus": {
             "type": "object",
             "properties": {
-                "allowDrafts": {
-                    "type": "boolean",
-                    "example": false
-                },
-                "collectEmail": {
-                    "type": "boolean",
-                    "example": true
-                },
-                "customCss": {
-                    "type": "string",
-                    "maxLength": 5000
-                },
-                "metadata": {
-                    "type": "object",
-                    "additionalProperties": true
+                "effective_spam_protection": {
+                    "enum": [
+                        "none",
+                        "captcha",
+                        "honeypot"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.SpamProtection"
+                        }
+                    ]
                 },
-                "notifyOnSubmission": {
-                    "type": "boolean",
-                    "example": true
+                "form_id": {
+                    "type": "string"
                 },
-                "redirectUrl": {
-                    "type": "string",
-                    "maxLength": 500
+                "spam_protection": {
+                    "description": "SpamProtection is the configured challenge, EffectiveSpamProtection\nthe one submissions must pass: captcha while throttled",
+                    "enum": [
+                        "none",
+                        "captcha",
+                        "honeypot"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.SpamProtection"
+                        }
+                    ]
                 },
-                "requireLogin": {
-                    "type": "boolean",
-                    "example": false
+                "throttled": {
+                    "type": "boolean"
                 },
-                "showProgressBar": {
-                    "type": "boolean",
-                    "example": true
+                "throttled_at": {
+                    "type": "string"
                 },
-                "thankYouMessage": {
-                    "type": "string",
-                    "maxLength": 1000
+                "throttling": {
+                    "description": "Throttling are the thresholds of the form, with the defaults filled in",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ThrottleSettings"
+                        }
+                    ]
                 }
             }
         },
-        "dto.FormStatisticsDTO": {
+        "service.PublicQuestionResults": {
             "type": "object",
             "properties": {
-                "averageTimeSeconds": {
-                    "type": "integer",
-                    "example": 120
-                },
-                "completionRate": {
-                    "type": "number",
-                    "example": 0.85
+                "distribution": {
+                    "description": "Distribution is the number of responses choosing each answer",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
                 },
-                "lastResponse": {
-                    "type": "string",
-                    "example": "2024-01-01T12:00:00Z"
+                "question_id": {
+                    "type": "string"
                 },
-                "responseRate": {
-                    "type": "number",
-                    "example": 0.75
+                "responses": {
+                    "type": "integer"
                 },
-                "totalResponses": {
-                    "type": "integer",
-                    "example": 150
+                "title": {
+                    "type": "string"
                 },
-                "uniqueResponders": {
-                    "type": "integer",
-                    "example": 140
+                "type": {
+                    "$ref": "#/definitions/models.QuestionType"
                 }
             }
         },
-        "dto.FormSummaryDTO": {
+        "service.PublicResults": {
             "type": "object",
             "properties": {
-                "createdAt": {
-                    "type": "string",
-                    "example": "2024-01-01T12:00:00Z"
-                },
-                "createdBy": {
-                    "$ref": "#/definitions/dto.UserInfoDTO"
-                },
-                "description": {
-                    "type": "string",
-                    "example": "Please provide your feedback"
-                },
-                "expiresAt": {
-                    "type": "string",
-                    "example": "2024-12-31T23:59:59Z"
-                },
-                "id": {
-                    "type": "string",
-                    "example": "f123e4567-e89b-12d3-a456-426614174000"
-                },
-                "isPublic": {
-                    "type": "boolean",
-                    "example": true
-                },
-                "publishedAt": {
-                    "type": "string",
-                    "example": "2024-01-01T12:00:00Z"
-                },
-                "statistics": {
-                    "$ref": "#/definitions/dto.FormStatisticsDTO"
-                },
-                "status": {
-                    "type": "string",
-                    "example": "published"
+                "form_id": {
+                    "type": "string"
                 },
-                "title": {
-                    "type": "string",
-                    "example": "Customer Feedback Form"
+                "min_responses": {
+                    "description": "MinResponses is the number of responses a question needs for its\nresults to be published",
+                    "type": "integer",
+                    "example": 5
                 },
-                "updatedAt": {
-                    "type": "string",
-                    "example": "2024-01-01T12:00:00Z"
+                "questions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.PublicQuestionResults"
+                    }
                 }
             }
         },
-        "dto.HealthCheckResponseDTO": {
+        "service.ReceiptRequest": {
             "type": "object",
+            "required": [
+                "response_id",
+                "submitted_at"
+            ],
             "properties": {
-                "dependencies": {
-                    "$ref": "#/definitions/dto.HealthDependenciesDTO"
-                },
-                "environment": {
-                    "type": "string",
-                    "example": "production"
-                },
-                "features": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
-                },
-                "metrics": {
-                    "$ref": "#/definitions/dto.HealthMetricsDTO"
+                "answers": {
+                    "description": "Answers are keyed by question ID",
+                    "type": "object"
                 },
-                "service": {
+                "locale": {
+                    "description": "Locale picks the translation of the titles, the default locale of\nthe form otherwise",
                     "type": "string",
-                    "example": "form-service"
+                    "example": "he"
                 },
-                "status": {
+                "response_id": {
                     "type": "string",
-                    "example": "healthy"
+                    "example": "resp_1718031234_ab12cd"
                 },
-                "timestamp": {
-                    "type": "string",
-                    "example": "2024-01-01T12:00:00Z"
+                "revision": {
+                    "type": "integer",
+                    "example": 1
                 },
-                "uptime": {
-                    "type": "string",
-                    "example": "72h30m45s"
+                "submitted_at": {
+                    "type": "string"
                 },
-                "version": {
-                    "type": "string",
-                    "example": "1.0.0"
+                "user_id": {
+                    "description": "UserID is set when the receipt is asked for by a user other than the\nrespondent, who must be allowed to view the form",
+                    "type": "string"
                 }
             }
         },
-        "dto.HealthDependenciesDTO": {
+        "service.RenameQuestionKeyRequest": {
             "type": "object",
+            "required": [
+                "key"
+            ],
             "properties": {
-                "authService": {
-                    "$ref": "#/definitions/dto.DependencyStatusDTO"
-                },
-                "database": {
-                    "$ref": "#/definitions/dto.DependencyStatusDTO"
-                },
-                "emailService": {
-                    "$ref": "#/definitions/dto.DependencyStatusDTO"
-                },
-                "storageService": {
-                    "$ref": "#/definitions/dto.DependencyStatusDTO"
+                "key": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "age_group"
                 }
             }
         },
-        "dto.HealthMetricsDTO": {
+        "service.ReportScheduleRequest": {
             "type": "object",
+            "required": [
+                "frequency"
+            ],
             "properties": {
-                "activeConnections": {
-                    "type": "integer",
-                    "example": 25
+                "format": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ReportFormat"
+                        }
+                    ],
+                    "example": "html"
                 },
-                "averageResponseTimeMs": {
-                    "type": "integer",
-                    "example": 150
+                "frequency": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ReportFrequency"
+                        }
+                    ],
+                    "example": "weekly"
                 },
-                "cpuUsagePercent": {
-                    "type": "number",
-                    "example": 45.2
+                "include_empty": {
+                    "description": "IncludeEmpty also reports periods without responses",
+                    "type": "boolean"
                 },
-                "diskUsagePercent": {
-                    "type": "number",
-                    "example": 35.8
+                "recipients": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "owner@example.com"
+                    ]
                 },
-                "errorRate": {
-                    "type": "number",
-                    "example": 0.001
+                "send_at": {
+                    "description": "SendAt is the local time of day reports run at, 09:00 by default",
+                    "type": "string",
+                    "example": "09:00"
                 },
-                "memoryUsagePercent": {
-                    "type": "number",
-                    "example": 65.5
+                "timezone": {
+                    "description": "Timezone is an IANA time zone, UTC by default",
+                    "type": "string",
+                    "example": "Europe/Berlin"
                 },
-                "requestsPerSecond": {
-                    "type": "number",
-                    "example": 10.5
+                "weekday": {
+                    "description": "Weekday is the day weekly reports run on, 0 being Sunday",
+                    "type": "integer",
+                    "example": 1
                 }
             }
         },
-        "dto.PaginatedResponse": {
+        "service.ResolvedSlug": {
             "type": "object",
             "properties": {
-                "correlationId": {
-                    "type": "string"
+                "form": {
+                    "$ref": "#/definitions/models.Form"
                 },
-                "data": {},
-                "message": {
+                "moved_to": {
+                    "description": "MovedTo is the current slug of the form the slug used to name",
                     "type": "string"
                 },
-                "pagination": {
-                    "$ref": "#/definitions/dto.Pagination"
+                "questions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Question"
+                    }
                 },
-                "success": {
-                    "type": "boolean"
+                "response_policy": {
+                    "$ref": "#/definitions/models.ResponsePolicy"
                 },
-                "timestamp": {
-                    "type": "string"
+                "shuffle_seed": {
+                    "description": "ShuffleSeed seeds the shuffle of the questions with randomized\noptions, omitted when no question randomizes or the respondent is\nunknown",
+                    "type": "integer"
                 },
-                "version": {
+                "spam_protection": {
+                    "description": "SpamProtection is the challenge submissions must pass, omitted for\nforms without spam protection",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.SpamChallenge"
+                        }
+                    ]
+                },
+                "view_token": {
+                    "description": "ViewToken is the view token the funnel beacons of the respondent\ncarry, omitted when the respondent is unknown",
                     "type": "string"
                 }
             }
         },
-        "dto.Pagination": {
+        "service.ResponseDeletionPreview": {
             "type": "object",
             "properties": {
-                "hasNext": {
-                    "type": "boolean"
+                "confirmation_token": {
+                    "description": "ConfirmationToken starts the deletion until ExpiresAt. It is not\nissued when nothing matches.",
+                    "type": "string"
                 },
-                "hasPrev": {
+                "exceeds_cap": {
+                    "description": "ExceedsCap is set when the responses matched are a greater share of\nthe responses of the form than MaxPercent, so deleting them needs\nthe override of an admin of the organization",
                     "type": "boolean"
                 },
-                "page": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "matched": {
                     "type": "integer"
                 },
-                "pageSize": {
+                "max_percent": {
                     "type": "integer"
                 },
                 "total": {
                     "type": "integer"
+                }
+            }
+        },
+        "service.SaveDraftRequest": {
+            "type": "object",
+            "required": [
+                "answers"
+            ],
+            "properties": {
+                "answers": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "service.SetThrottledRequest": {
+            "type": "object",
+            "properties": {
+                "baseline_per_minute": {
+                    "type": "number",
+                    "example": 2.5
                 },
-                "totalPages": {
-                    "type": "integer"
+                "rate_per_minute": {
+                    "type": "number",
+                    "example": 840
+                },
+                "throttled": {
+                    "type": "boolean"
                 }
             }
         },
-        "dto.PublishFormRequestDTO": {
+        "service.TestNotificationResponse": {
             "type": "object",
             "properties": {
                 "message": {
-                    "type": "string",
-                    "maxLength": 500,
-                    "example": "Form is now live and ready for responses"
-                },
-                "notifySubscribers": {
-                    "type": "boolean",
-                    "example": false
+                    "type": "string"
                 },
-                "scheduleAt": {
-                    "type": "string",
-                    "example": "2024-01-15T09:00:00Z"
+                "to": {
+                    "type": "string"
                 }
             }
         },
-        "dto.QuestionOptionDTO": {
+        "service.TestSubmissionRequest": {
             "type": "object",
             "required": [
-                "label",
-                "value"
+                "user_id"
             ],
             "properties": {
-                "label": {
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.TransferFormRequest": {
+            "type": "object",
+            "properties": {
+                "to_organization_id": {
                     "type": "string",
-                    "maxLength": 255,
-                    "example": "Option 1"
-                },
-                "order": {
-                    "type": "integer",
-                    "minimum": 0,
-                    "example": 1
+                    "format": "uuid"
                 },
-                "value": {
+                "to_user_id": {
                     "type": "string",
-                    "maxLength": 255,
-                    "example": "option1"
+                    "format": "uuid",
+                    "example": "5f0c6a3e-8a44-4c1f-9d43-2b6f3f3f8c11"
                 }
             }
         },
-        "dto.QuestionResponseDTO": {
+        "service.UpdateChannelRequest": {
             "type": "object",
             "properties": {
-                "conditional": {
-                    "$ref": "#/definitions/dto.ConditionalLogicDTO"
+                "enabled": {
+                    "type": "boolean"
                 },
-                "createdAt": {
-                    "type": "string",
-                    "example": "2024-01-01T12:00:00Z"
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "description": {
-                    "type": "string",
-                    "example": "Please enter your full name"
+                "include_answer_summary": {
+                    "type": "boolean"
                 },
-                "id": {
-                    "type": "string",
-                    "example": "q123e4567-e89b-12d3-a456-426614174000"
+                "name": {
+                    "type": "string"
+                },
+                "quiet_hours_end": {
+                    "type": "string"
+                },
+                "quiet_hours_start": {
+                    "type": "string"
+                },
+                "timezone": {
+                    "type": "string"
                 },
-                "label": {
+                "webhook_url": {
+                    "description": "WebhookURL replaces the webhook of the channel",
+                    "type": "string"
+                }
+            }
+        },
+        "service.UpdateCollaboratorRequest": {
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "enum": [
+                        "viewer",
+                        "editor",
+                        "manager"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.CollaboratorRole"
+                        }
+                    ],
+                    "example": "viewer"
+                }
+            }
+        },
+        "service.UpdateFormRequest": {
+            "type": "object",
+            "properties": {
+                "description": {
                     "type": "string",
-                    "example": "What is your name?"
+                    "maxLength": 2000
                 },
-                "metadata": {
-                    "type": "object",
-                    "additionalProperties": true
+                "retention_days": {
+                    "type": "integer",
+                    "maximum": 3650,
+                    "minimum": 0,
+                    "example": 365
                 },
-                "options": {
+                "rules": {
+                    "description": "Rules replaces the cross-field validation rules; an empty list\nremoves them",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/dto.QuestionOptionDTO"
+                        "$ref": "#/definitions/models.FormRule"
                     }
                 },
-                "order": {
-                    "type": "integer",
-                    "example": 1
-                },
-                "required": {
-                    "type": "boolean",
-                    "example": true
+                "settings": {
+                    "$ref": "#/definitions/models.FormSettings"
                 },
-                "type": {
+                "slug": {
                     "type": "string",
-                    "example": "text"
+                    "example": "feedback"
                 },
-                "updatedAt": {
+                "title": {
                     "type": "string",
-                    "example": "2024-01-01T12:00:00Z"
-                },
-                "validation": {
-                    "$ref": "#/definitions/dto.QuestionValidationDTO"
+                    "maxLength": 200
                 }
             }
         },
-        "dto.QuestionValidationDTO": {
+        "service.UpdateLibraryQuestionRequest": {
             "type": "object",
             "properties": {
-                "allowedTypes": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "options": {},
+                "tags": {
                     "type": "array",
                     "items": {
                         "type": "string"
-                    },
-                    "example": [
-                        "jpg",
-                        "png",
-                        "pdf"
-                    ]
-                },
-                "maxFileSize": {
-                    "type": "integer",
-                    "example": 5242880
-                },
-                "maxLength": {
-                    "type": "integer",
-                    "example": 100
-                },
-                "maxValue": {
-                    "type": "number",
-                    "example": 100
+                    }
                 },
-                "minLength": {
-                    "type": "integer",
-                    "example": 2
+                "title": {
+                    "type": "string",
+                    "maxLength": 500
                 },
-                "minValue": {
-                    "type": "number",
-                    "example": 0
+                "type": {
+                    "$ref": "#/definitions/models.QuestionType"
                 },
-                "pattern": {
-                    "type": "string",
-                    "example": "^[a-zA-Z ]+$"
-                }
+                "validation": {}
             }
         },
-        "dto.SuccessResponse": {
+        "service.UploadURLResponse": {
             "type": "object",
             "properties": {
-                "correlationId": {
+                "expires_at": {
                     "type": "string"
                 },
-                "data": {},
-                "message": {
+                "file_token": {
                     "type": "string"
                 },
-                "success": {
-                    "type": "boolean"
+                "headers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
                 },
-                "timestamp": {
+                "method": {
                     "type": "string"
                 },
-                "version": {
+                "upload_url": {
                     "type": "string"
                 }
             }
         },
-        "dto.UpdateFormRequestDTO": {
+        "service.UpsertTranslationRequest": {
             "type": "object",
             "properties": {
-                "allowMultiple": {
-                    "type": "boolean"
-                },
-                "category": {
-                    "type": "string",
-                    "maxLength": 100
-                },
                 "description": {
-                    "type": "string",
-                    "maxLength": 1000
-                },
-                "expiresAt": {
                     "type": "string"
                 },
-                "isAnonymous": {
-                    "type": "boolean"
-                },
-                "isPublic": {
-                    "type": "boolean"
-                },
-                "settings": {
-                    "$ref": "#/definitions/dto.FormSettingsDTO"
+                "questions": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.QuestionTranslation"
+                    }
                 },
-                "tags": {
-                    "type": "array",
-                    "maxItems": 10,
-                    "items": {
+                "rules": {
+                    "description": "Rules translates the messages of rules, keyed by rule ID",
+                    "type": "object",
+                    "additionalProperties": {
                         "type": "string"
                     }
                 },
                 "title": {
-                    "type": "string",
-                    "maxLength": 255,
-                    "minLength": 1
+                    "type": "string"
                 }
             }
         },
-        "dto.UserInfoDTO": {
+        "service.UsageReport": {
             "type": "object",
             "properties": {
-                "avatar": {
-                    "type": "string",
-                    "example": "https://example.com/avatar.jpg"
+                "active_forms": {
+                    "description": "ActiveForms are the forms not closed, counted against\nplan.max_active_forms",
+                    "type": "integer"
                 },
-                "email": {
-                    "type": "string",
-                    "example": "john@example.com"
+                "exports": {
+                    "type": "integer"
                 },
-                "id": {
-                    "type": "string",
-                    "example": "u123e4567-e89b-12d3-a456-426614174000"
+                "forms_created": {
+                    "type": "integer"
                 },
-                "name": {
-                    "type": "string",
-                    "example": "John Doe"
+                "month": {
+                    "type": "string"
                 },
-                "username": {
-                    "type": "string",
-                    "example": "john_doe"
-                }
-            }
-        },
-        "dto.ValidationErrorDTO": {
-            "type": "object",
-            "properties": {
-                "correlationId": {
+                "organization_id": {
                     "type": "string"
                 },
-                "error": {
-                    "$ref": "#/definitions/dto.ValidationErrorDetail"
+                "plan": {
+                    "$ref": "#/definitions/models.Plan"
                 },
-                "message": {
+                "reconciled_at": {
+                    "description": "ReconciledAt is when the usage was last checked against the source of\ntruth",
                     "type": "string"
                 },
-                "success": {
-                    "type": "boolean"
+                "responses": {
+                    "type": "integer"
                 },
-                "timestamp": {
-                    "type": "string"
+                "storage_bytes": {
+                    "type": "integer"
                 },
-                "version": {
+                "updated_at": {
                     "type": "string"
                 }
             }
         },
-        "dto.ValidationErrorDetail": {
+        "service.VerifyFileTokensRequest": {
             "type": "object",
+            "required": [
+                "response_id",
+                "tokens"
+            ],
             "properties": {
-                "code": {
+                "response_id": {
                     "type": "string",
-                    "example": "VALIDATION_ERROR"
+                    "maxLength": 100
                 },
-                "fields": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        }
+                "tokens": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
                     }
-                },
-                "message": {
-                    "type": "string",
-                    "example": "Input validation failed"
-                },
-                "metadata": {
-                    "type": "object",
-                    "additionalProperties": true
-                },
-                "path": {
-                    "type": "string"
-                },
-                "requestId": {
-                    "type": "string"
-                },
-                "timestamp": {
-                    "type": "string"
                 }
             }
         }
     },
     "securityDefinitions": {
         "BearerAuth": {
-            "description": "Type \"Bearer\" followed by a space and JWT token.",
+            "description": "JWT issued by the auth service, sent as \"Bearer \u003ctoken\u003e\"",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        },
+        "ServiceToken": {
+            "description": "INTERNAL_SERVICE_TOKEN shared with the calling services, sent as \"Bearer \u003ctoken\u003e\"",
             "type": "apiKey",
             "name": "Authorization",
             "in": "header"
@@ -1940,11 +9780,11 @@ const docTemplate = `{
 // SwaggerInfo holds exported Swagger Info so clients can modify it
 var SwaggerInfo = &swag.Spec{
 	Version:          "1.0.0",
-	Host:             "localhost:8080",
-	BasePath:         "/api/v1",
+	Host:             "",
+	BasePath:         "/",
 	Schemes:          []string{},
 	Title:            "Form Service API",
-	Description:      "Comprehensive form management service built with Clean Architecture and microservices best practices",
+	Description:      "Form management, uploads and response drafts. Errors are returned as {\"error\": \"...\"}.",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
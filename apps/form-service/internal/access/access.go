@@ -0,0 +1,62 @@
+// Package access decides what the owner and the collaborators of a form may
+// do with it. The roles are cumulative: an editor can do everything a viewer
+// can, a manager everything an editor can, and the owner everything.
+package access
+
+import "github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+
+// Action is something a user does with a form
+type Action string
+
+const (
+	// View reads the form, its questions and its collaborators
+	View Action = "view"
+	// Edit changes the form details, translations and questions
+	Edit Action = "edit"
+	// Publish publishes the form
+	Publish Action = "publish"
+	// Delete deletes the form
+	Delete Action = "delete"
+	// ManageCollaborators invites, updates and removes collaborators
+	ManageCollaborators Action = "manage_collaborators"
+	// DeleteResponses deletes responses of the form in bulk
+	DeleteResponses Action = "delete_responses"
+	// SubmitTest submits test responses to the published form
+	SubmitTest Action = "submit_test"
+	// PurgeTestResponses deletes the test responses of the form
+	PurgeTestResponses Action = "purge_test_responses"
+	// ManageNotifications adds, changes, tests and removes the notification
+	// channels of the form and sends test notifications
+	ManageNotifications Action = "manage_notifications"
+)
+
+// minimumRole is the least role allowed each action
+var minimumRole = map[Action]models.CollaboratorRole{
+	View:                models.CollaboratorRoleViewer,
+	Edit:                models.CollaboratorRoleEditor,
+	Publish:             models.CollaboratorRoleManager,
+	Delete:              models.CollaboratorRoleManager,
+	ManageCollaborators: models.CollaboratorRoleManager,
+	DeleteResponses:     models.CollaboratorRoleManager,
+	SubmitTest:          models.CollaboratorRoleViewer,
+	PurgeTestResponses:  models.CollaboratorRoleEditor,
+	ManageNotifications: models.CollaboratorRoleManager,
+}
+
+// rank orders the roles
+var rank = map[models.CollaboratorRole]int{
+	models.CollaboratorRoleViewer:  1,
+	models.CollaboratorRoleEditor:  2,
+	models.CollaboratorRoleManager: 3,
+	models.CollaboratorRoleOwner:   4,
+}
+
+// Allowed reports whether role allows action. Unknown roles and actions
+// allow nothing.
+func Allowed(role models.CollaboratorRole, action Action) bool {
+	minimum, ok := minimumRole[action]
+	if !ok {
+		return false
+	}
+	return rank[role] >= rank[minimum]
+}
@@ -0,0 +1,52 @@
+package access
+
+import (
+	"testing"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+)
+
+func TestAllowed(t *testing.T) {
+	tests := []struct {
+		role    models.CollaboratorRole
+		action  Action
+		allowed bool
+	}{
+		{models.CollaboratorRoleViewer, View, true},
+		{models.CollaboratorRoleViewer, Edit, false},
+		{models.CollaboratorRoleViewer, Publish, false},
+		{models.CollaboratorRoleViewer, ManageCollaborators, false},
+		{models.CollaboratorRoleViewer, SubmitTest, true},
+		{models.CollaboratorRoleViewer, PurgeTestResponses, false},
+
+		{models.CollaboratorRoleEditor, View, true},
+		{models.CollaboratorRoleEditor, Edit, true},
+		{models.CollaboratorRoleEditor, Publish, false},
+		{models.CollaboratorRoleEditor, Delete, false},
+		{models.CollaboratorRoleEditor, ManageCollaborators, false},
+		{models.CollaboratorRoleEditor, DeleteResponses, false},
+		{models.CollaboratorRoleEditor, PurgeTestResponses, true},
+		{models.CollaboratorRoleEditor, ManageNotifications, false},
+
+		{models.CollaboratorRoleManager, Edit, true},
+		{models.CollaboratorRoleManager, Publish, true},
+		{models.CollaboratorRoleManager, Delete, true},
+		{models.CollaboratorRoleManager, ManageCollaborators, true},
+		{models.CollaboratorRoleManager, DeleteResponses, true},
+		{models.CollaboratorRoleManager, ManageNotifications, true},
+
+		{models.CollaboratorRoleOwner, View, true},
+		{models.CollaboratorRoleOwner, Delete, true},
+		{models.CollaboratorRoleOwner, ManageCollaborators, true},
+
+		{"admin", View, false},
+		{"", View, false},
+		{models.CollaboratorRoleOwner, "transfer", false},
+	}
+
+	for _, tt := range tests {
+		if got := Allowed(tt.role, tt.action); got != tt.allowed {
+			t.Errorf("Allowed(%q, %q) = %v, want %v", tt.role, tt.action, got, tt.allowed)
+		}
+	}
+}
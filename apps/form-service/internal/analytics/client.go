@@ -0,0 +1,151 @@
+// Package analytics reads form summaries and answer distributions from the
+// analytics service, which aggregates the responses of forms, and runs
+// drill-down queries over the response projection of the event store
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrNotConfigured is returned when no analytics service URL is set
+var ErrNotConfigured = errors.New("analytics service is not configured")
+
+// Summary is the aggregate of the responses of a form over a period
+type Summary struct {
+	FormID             string `json:"form_id"`
+	Title              string `json:"title"`
+	TotalResponses     int    `json:"total_responses"`
+	CompletedResponses int    `json:"completed_responses"`
+	PartialResponses   int    `json:"partial_responses"`
+	// AverageCompletionTime is in seconds, nil without completed responses
+	AverageCompletionTime *float64     `json:"average_completion_time"`
+	CompletionRate        float64      `json:"completion_rate"`
+	UniqueRespondents     int          `json:"unique_respondents"`
+	Trend                 []TrendPoint `json:"response_rate_trend"`
+}
+
+// TrendPoint is the number of responses of a day
+type TrendPoint struct {
+	Date  string `json:"date"`
+	Count int    `json:"count"`
+}
+
+// Aggregator computes the summary of a form over [start, end)
+type Aggregator interface {
+	Summary(ctx context.Context, formID string, start, end time.Time) (*Summary, error)
+}
+
+// Distribution is the number of responses answering a question and how many
+// chose each answer
+type Distribution struct {
+	QuestionID     string         `json:"question_id"`
+	TotalResponses int            `json:"total_responses"`
+	Answers        map[string]int `json:"answer_distribution"`
+}
+
+// Distributor computes the answer distribution of a question
+type Distributor interface {
+	QuestionDistribution(ctx context.Context, formID, questionID, questionType string) (*Distribution, error)
+}
+
+// Client calls the summary and question endpoints of the analytics service
+type Client struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// NewClient creates a client of the analytics service at baseURL,
+// authenticating with token as a bearer token when it is set
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Summary returns the summary of a form, bypassing the analytics cache so
+// a report reflects every response of its period
+func (c *Client) Summary(ctx context.Context, formID string, start, end time.Time) (*Summary, error) {
+	if c.baseURL == "" {
+		return nil, ErrNotConfigured
+	}
+
+	query := url.Values{}
+	query.Set("start_date", start.UTC().Format(time.RFC3339))
+	query.Set("end_date", end.UTC().Format(time.RFC3339))
+	query.Set("use_cache", "false")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		c.baseURL+"/analytics/"+url.PathEscape(formID)+"/summary?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var body struct {
+		Data struct {
+			Summary Summary `json:"summary"`
+		} `json:"data"`
+	}
+	if err := c.get(req, "summary of form "+formID, &body); err != nil {
+		return nil, err
+	}
+	return &body.Data.Summary, nil
+}
+
+// QuestionDistribution returns the answer distribution of a question of a
+// form over all its responses. Callers cache it; the analytics cache is
+// used as well.
+func (c *Client) QuestionDistribution(ctx context.Context, formID, questionID, questionType string) (*Distribution, error) {
+	if c.baseURL == "" {
+		return nil, ErrNotConfigured
+	}
+
+	query := url.Values{}
+	query.Set("question_type", questionType)
+	query.Set("use_cache", "true")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		c.baseURL+"/analytics/"+url.PathEscape(formID)+"/question/"+url.PathEscape(questionID)+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var body struct {
+		Data Distribution `json:"data"`
+	}
+	if err := c.get(req, "question "+questionID+" of form "+formID, &body); err != nil {
+		return nil, err
+	}
+	body.Data.QuestionID = questionID
+	return &body.Data, nil
+}
+
+// get sends req and decodes the body of its answer into v. what names the
+// resource in errors.
+func (c *Client) get(req *http.Request, what string, v interface{}) error {
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to get analytics of %s: %w", what, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("analytics service answered %d for the %s", resp.StatusCode, what)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("invalid analytics of %s: %w", what, err)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Funnel ranges are days, given as YYYY-MM-DD
+const (
+	funnelDayLayout = "2006-01-02"
+	// DefaultFunnelDays is the length of the range reported when none is
+	// asked for, ending today
+	DefaultFunnelDays = 30
+	// MaxFunnelDays bounds the length of the range of a funnel report
+	MaxFunnelDays = 366
+)
+
+// ErrInvalidRange is returned for funnel ranges that aren't days in order,
+// or are longer than MaxFunnelDays
+var ErrInvalidRange = errors.New("invalid funnel range")
+
+// FunnelReader reads the daily funnel counts of forms from the
+// form_funnel_daily projection
+type FunnelReader interface {
+	// FunnelDays returns the counts of the UTC days of a form in [from, to],
+	// oldest first, leaving out the days without any
+	FunnelDays(ctx context.Context, formID string, from, to time.Time) ([]FunnelDay, error)
+}
+
+// FunnelCounts are the sessions of respondents reaching each stage of the
+// funnel of a form. A session is counted once per stage, on the day it
+// reached it; test submissions are never counted.
+type FunnelCounts struct {
+	Views       int64 `json:"views" example:"1200"`
+	Starts      int64 `json:"starts" example:"540"`
+	Submissions int64 `json:"submissions" example:"410"`
+}
+
+// FunnelRates are the shares of the sessions of a stage reaching the next:
+// StartRate is starts per view, CompletionRate submissions per start and
+// ConversionRate submissions per view. Sessions may reach a stage the day
+// after the previous one, and submissions be sent without a view beacon,
+// so rates are capped at 1; they are 0 without sessions to divide by.
+type FunnelRates struct {
+	StartRate      float64 `json:"start_rate" example:"0.45"`
+	CompletionRate float64 `json:"completion_rate" example:"0.7593"`
+	ConversionRate float64 `json:"conversion_rate" example:"0.3417"`
+}
+
+// FunnelDay is the funnel of a form on a UTC day
+type FunnelDay struct {
+	Date string `json:"date" example:"2026-10-16"`
+	FunnelCounts
+	FunnelRates
+	// Partial is set for today, whose sessions are still being counted
+	Partial bool `json:"partial,omitempty"`
+}
+
+// FunnelReport is the daily funnel of a form over a range of days
+type FunnelReport struct {
+	FormID string `json:"form_id"`
+	From   string `json:"from" example:"2026-09-17"`
+	To     string `json:"to" example:"2026-10-16"`
+	// Totals counts the sessions of every day of the range. Its rates are
+	// those of the complete days, as the sessions of today may still
+	// convert, unless today is the only day of the range.
+	Totals FunnelTotals `json:"totals"`
+	// Days are the days of the range, oldest first, those without sessions
+	// included
+	Days        []FunnelDay `json:"days"`
+	GeneratedAt time.Time   `json:"generated_at"`
+} // @name FunnelReport
+
+// FunnelTotals is the funnel of a form over the days of a report
+type FunnelTotals struct {
+	FunnelCounts
+	FunnelRates
+} // @name FunnelTotals
+
+// ParseFunnelRange parses the from and to days of a funnel report, both
+// included. to defaults to today and from to DefaultFunnelDays days before
+// it; a to after today is today, the funnel of later days being empty.
+func ParseFunnelRange(from, to string, now time.Time) (time.Time, time.Time, error) {
+	today := now.UTC().Truncate(24 * time.Hour)
+	end := today
+	if to != "" {
+		parsed, err := time.Parse(funnelDayLayout, to)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: to must be a day as YYYY-MM-DD", ErrInvalidRange)
+		}
+		if parsed.Before(today) {
+			end = parsed
+		}
+	}
+	start := end.AddDate(0, 0, 1-DefaultFunnelDays)
+	if from != "" {
+		parsed, err := time.Parse(funnelDayLayout, from)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: from must be a day as YYYY-MM-DD", ErrInvalidRange)
+		}
+		start = parsed
+	}
+	if start.After(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: from is after to", ErrInvalidRange)
+	}
+	if days := int(end.Sub(start)/(24*time.Hour)) + 1; days > MaxFunnelDays {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: at most %d days are reported at once", ErrInvalidRange, MaxFunnelDays)
+	}
+	return start, end, nil
+}
+
+// BuildFunnel builds the funnel report of a form over the days from from to
+// to from the counts of the days with sessions, read by a FunnelReader
+func BuildFunnel(formID string, counted []FunnelDay, from, to, now time.Time) *FunnelReport {
+	byDate := make(map[string]FunnelCounts, len(counted))
+	for _, day := range counted {
+		byDate[day.Date] = day.FunnelCounts
+	}
+	today := now.UTC().Format(funnelDayLayout)
+
+	report := &FunnelReport{
+		FormID:      formID,
+		From:        from.Format(funnelDayLayout),
+		To:          to.Format(funnelDayLayout),
+		Days:        []FunnelDay{},
+		GeneratedAt: now.UTC(),
+	}
+	var complete FunnelCounts
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format(funnelDayLayout)
+		counts := byDate[date]
+		report.Days = append(report.Days, FunnelDay{
+			Date:         date,
+			FunnelCounts: counts,
+			FunnelRates:  counts.Rates(),
+			Partial:      date == today,
+		})
+		report.Totals.FunnelCounts = report.Totals.add(counts)
+		if date != today {
+			complete = complete.add(counts)
+		}
+	}
+
+	if len(report.Days) == 1 && report.Days[0].Partial {
+		complete = report.Totals.FunnelCounts
+	}
+	report.Totals.FunnelRates = complete.Rates()
+	return report
+}
+
+// Rates returns the rates of the counts
+func (c FunnelCounts) Rates() FunnelRates {
+	return FunnelRates{
+		StartRate:      rate(c.Starts, c.Views),
+		CompletionRate: rate(c.Submissions, c.Starts),
+		ConversionRate: rate(c.Submissions, c.Views),
+	}
+}
+
+// add returns the sum of the counts
+func (c FunnelCounts) add(other FunnelCounts) FunnelCounts {
+	return FunnelCounts{
+		Views:       c.Views + other.Views,
+		Starts:      c.Starts + other.Starts,
+		Submissions: c.Submissions + other.Submissions,
+	}
+}
+
+// rate returns n per d, capped at 1, and 0 for d 0
+func rate(n, d int64) float64 {
+	if d <= 0 {
+		return 0
+	}
+	if n >= d {
+		return 1
+	}
+	return float64(n) / float64(d)
+}
@@ -0,0 +1,130 @@
+package analytics
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBuildFunnel(t *testing.T) {
+	now := time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC)
+	from, to, err := ParseFunnelRange("2026-10-13", "", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counted := []FunnelDay{
+		{Date: "2026-10-13", FunnelCounts: FunnelCounts{Views: 100, Starts: 40, Submissions: 30}},
+		// Sessions viewed before midnight start after it
+		{Date: "2026-10-15", FunnelCounts: FunnelCounts{Views: 50, Starts: 60, Submissions: 20}},
+		// Today is still being counted
+		{Date: "2026-10-16", FunnelCounts: FunnelCounts{Views: 30, Starts: 5}},
+	}
+
+	report := BuildFunnel("form-1", counted, from, to, now)
+	if report.From != "2026-10-13" || report.To != "2026-10-16" {
+		t.Errorf("range = %s to %s, want 2026-10-13 to 2026-10-16", report.From, report.To)
+	}
+	want := []FunnelDay{
+		{Date: "2026-10-13", FunnelCounts: FunnelCounts{Views: 100, Starts: 40, Submissions: 30},
+			FunnelRates: FunnelRates{StartRate: 0.4, CompletionRate: 0.75, ConversionRate: 0.3}},
+		// Days without sessions are reported empty
+		{Date: "2026-10-14"},
+		// Rates over 1 are capped
+		{Date: "2026-10-15", FunnelCounts: FunnelCounts{Views: 50, Starts: 60, Submissions: 20},
+			FunnelRates: FunnelRates{StartRate: 1, CompletionRate: 20.0 / 60, ConversionRate: 0.4}},
+		{Date: "2026-10-16", FunnelCounts: FunnelCounts{Views: 30, Starts: 5},
+			FunnelRates: FunnelRates{StartRate: 5.0 / 30}, Partial: true},
+	}
+	if len(report.Days) != len(want) {
+		t.Fatalf("got %d days, want %d", len(report.Days), len(want))
+	}
+	for i, w := range want {
+		got := report.Days[i]
+		if got.Date != w.Date || got.FunnelCounts != w.FunnelCounts || got.Partial != w.Partial || !ratesEqual(got.FunnelRates, w.FunnelRates) {
+			t.Errorf("day %d = %+v, want %+v", i, got, w)
+		}
+	}
+
+	// Every day is counted, but the rates leave the partial day out
+	if totals := report.Totals.FunnelCounts; totals != (FunnelCounts{Views: 180, Starts: 105, Submissions: 50}) {
+		t.Errorf("totals = %+v, want the sums of every day", totals)
+	}
+	wantRates := FunnelRates{StartRate: 100.0 / 150, CompletionRate: 0.5, ConversionRate: 50.0 / 150}
+	if !ratesEqual(report.Totals.FunnelRates, wantRates) {
+		t.Errorf("total rates = %+v, want %+v over the complete days", report.Totals.FunnelRates, wantRates)
+	}
+}
+
+func TestBuildFunnelOfToday(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	from, to, err := ParseFunnelRange("2026-10-16", "2026-10-16", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A range of today alone is rated on its partial counts
+	report := BuildFunnel("form-1", []FunnelDay{
+		{Date: "2026-10-16", FunnelCounts: FunnelCounts{Views: 8, Starts: 4, Submissions: 1}},
+	}, from, to, now)
+	if len(report.Days) != 1 || !report.Days[0].Partial {
+		t.Fatalf("days = %+v, want today, partial", report.Days)
+	}
+	want := FunnelRates{StartRate: 0.5, CompletionRate: 0.25, ConversionRate: 0.125}
+	if !ratesEqual(report.Totals.FunnelRates, want) {
+		t.Errorf("total rates = %+v, want %+v", report.Totals.FunnelRates, want)
+	}
+
+	// Submissions sent without any view beacon don't divide by zero
+	empty := BuildFunnel("form-1", []FunnelDay{
+		{Date: "2026-10-16", FunnelCounts: FunnelCounts{Submissions: 3}},
+	}, from, to, now)
+	if rates := empty.Totals.FunnelRates; rates != (FunnelRates{}) {
+		t.Errorf("rates without views = %+v, want 0", rates)
+	}
+}
+
+func TestParseFunnelRange(t *testing.T) {
+	now := time.Date(2026, 10, 16, 23, 59, 0, 0, time.UTC)
+	day := func(value string) time.Time {
+		parsed, _ := time.Parse("2006-01-02", value)
+		return parsed
+	}
+
+	for _, tt := range []struct {
+		name, from, to string
+		wantFrom       string
+		wantTo         string
+	}{
+		{"default", "", "", "2026-09-17", "2026-10-16"},
+		{"to only", "", "2026-06-30", "2026-06-01", "2026-06-30"},
+		{"future to", "2026-10-01", "2026-12-31", "2026-10-01", "2026-10-16"},
+		{"longest range", "2025-10-16", "2026-10-16", "2025-10-16", "2026-10-16"},
+	} {
+		from, to, err := ParseFunnelRange(tt.from, tt.to, now)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !from.Equal(day(tt.wantFrom)) || !to.Equal(day(tt.wantTo)) {
+			t.Errorf("%s: range = %s to %s, want %s to %s", tt.name, from, to, tt.wantFrom, tt.wantTo)
+		}
+	}
+
+	for _, tt := range []struct{ name, from, to string }{
+		{"from after to", "2026-10-10", "2026-10-01"},
+		{"too long", "2025-10-15", "2026-10-16"},
+		{"timestamp", "2026-10-01T00:00:00Z", ""},
+		{"bad to", "", "yesterday"},
+	} {
+		if _, _, err := ParseFunnelRange(tt.from, tt.to, now); !errors.Is(err, ErrInvalidRange) {
+			t.Errorf("%s: err = %v, want ErrInvalidRange", tt.name, err)
+		}
+	}
+}
+
+func ratesEqual(a, b FunnelRates) bool {
+	return math.Abs(a.StartRate-b.StartRate) < 1e-9 &&
+		math.Abs(a.CompletionRate-b.CompletionRate) < 1e-9 &&
+		math.Abs(a.ConversionRate-b.ConversionRate) < 1e-9
+}
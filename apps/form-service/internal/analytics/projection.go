@@ -0,0 +1,337 @@
+package analytics
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Querier runs analytics queries over the responses of forms
+type Querier interface {
+	Query(ctx context.Context, formID string, query *Query) (*QueryResult, error)
+	// DistinctAnswers counts the distinct answers to a question, up to
+	// limit + 1
+	DistinctAnswers(ctx context.Context, formID string, question QuestionRef, limit int) (int, error)
+}
+
+// ResponseReader reads the responses of forms, for exports
+type ResponseReader interface {
+	// Responses returns up to limit responses of a form submitted after the
+	// cursor, in submission order, with the test submissions if includeTest
+	Responses(ctx context.Context, formID string, after ResponseCursor, limit int, includeTest bool) ([]Response, error)
+	// ResponseTimings returns the timings of responses of a form, by
+	// response ID, for the exports including them. Responses submitted
+	// without timings are left out.
+	ResponseTimings(ctx context.Context, formID string, responseIDs []string) (map[string]map[string]QuestionTiming, error)
+}
+
+// ResponseCounter counts the responses of forms, for usage reconciliation
+type ResponseCounter interface {
+	// CountResponses counts the responses to the forms first submitted in
+	// [start, end), test submissions aside
+	CountResponses(ctx context.Context, formIDs []string, start, end time.Time) (int64, error)
+}
+
+// Response is a response of a form with the latest answer to each question
+type Response struct {
+	ID          string
+	SubmittedAt time.Time
+	// RespondentID is empty for anonymous responses
+	RespondentID string
+	// Answers are the answers by question ID
+	Answers map[string]json.RawMessage
+	// Keys are the keys the answers were projected under, by question ID,
+	// for the answers projected with one
+	Keys map[string]string
+	// PresentedOrder is the order the options of questions with randomized
+	// options were shown in, as option keys by question ID
+	PresentedOrder map[string][]string
+	// Test is set for the test submissions of the owners and collaborators
+	// of the form
+	Test bool
+}
+
+// ResponseCursor is the last response of a page, zero for the first page
+type ResponseCursor struct {
+	SubmittedAt time.Time
+	ID          string
+}
+
+// Projection runs queries over the response_events, response_timings and
+// form_funnel_daily projections the event bus writes to the event store database, read
+// directly for the queries the analytics service doesn't answer. Test submissions are left out of every
+// count, and out of the responses read unless asked for.
+type Projection struct {
+	db *sql.DB
+}
+
+// NewProjection creates a projection reader over db. A nil db makes every
+// query fail with ErrNotConfigured.
+func NewProjection(db *sql.DB) *Projection {
+	return &Projection{db: db}
+}
+
+// Query runs a validated query over the responses of a form
+func (p *Projection) Query(ctx context.Context, formID string, query *Query) (*QueryResult, error) {
+	if p.db == nil {
+		return nil, ErrNotConfigured
+	}
+
+	statement, args := query.BuildSQL(formID)
+	rows, err := p.db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query responses: %w", err)
+	}
+	defer rows.Close()
+
+	var results []QueryRow
+	for rows.Next() {
+		var row QueryRow
+		if err := rows.Scan(&row.Group, &row.Answer, &row.Count, &row.GroupResponses, &row.Responses); err != nil {
+			return nil, fmt.Errorf("failed to read responses: %w", err)
+		}
+		results = append(results, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query responses: %w", err)
+	}
+	return Assemble(formID, query.Question, results), nil
+}
+
+// DistinctAnswers counts the distinct answers to a question of a form,
+// counting no further than limit + 1
+func (p *Projection) DistinctAnswers(ctx context.Context, formID string, question QuestionRef, limit int) (int, error) {
+	if p.db == nil {
+		return 0, ErrNotConfigured
+	}
+
+	var distinct int
+	err := p.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM (
+			SELECT DISTINCT answer FROM response_events
+			WHERE form_id = $1 AND (question_id = $2 OR question_key = ANY($3)) AND NOT is_test
+			LIMIT $4
+		) answers`, formID, question.ID, append([]string{}, question.Keys...), limit+1).Scan(&distinct)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count answers: %w", err)
+	}
+	return distinct, nil
+}
+
+// Responses reads a page of responses, each at the time it was first
+// submitted with the answers of its latest revision and the keys they were
+// projected under. Anonymous responses never carry their respondent.
+func (p *Projection) Responses(ctx context.Context, formID string, after ResponseCursor, limit int, includeTest bool) ([]Response, error) {
+	if p.db == nil {
+		return nil, ErrNotConfigured
+	}
+
+	rows, err := p.db.QueryContext(ctx, `
+		WITH page AS (
+			SELECT response_id, MIN(submitted_at) AS submitted_at, BOOL_OR(is_test) AS is_test
+			FROM response_events
+			WHERE form_id = $1 AND ($5 OR NOT is_test)
+			GROUP BY response_id
+			HAVING (MIN(submitted_at), response_id) > ($2, $3)
+			ORDER BY submitted_at, response_id
+			LIMIT $4
+		)
+		SELECT DISTINCT ON (p.submitted_at, p.response_id, e.question_id)
+			p.response_id, p.submitted_at, e.question_id, COALESCE(e.question_key, ''), e.answer,
+			CASE WHEN e.is_anonymous THEN '' ELSE COALESCE(e.respondent_id, '') END,
+			e.presented_order, p.is_test
+		FROM page p
+		JOIN response_events e ON e.form_id = $1 AND e.response_id = p.response_id
+		ORDER BY p.submitted_at, p.response_id, e.question_id, e.revision DESC, e.submitted_at DESC`,
+		formID, after.SubmittedAt.UTC(), after.ID, limit, includeTest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read responses: %w", err)
+	}
+	defer rows.Close()
+
+	var responses []Response
+	for rows.Next() {
+		var (
+			id, questionID, questionKey, respondentID string
+			submittedAt                               time.Time
+			answer, presentedOrder                    []byte
+			test                                      bool
+		)
+		if err := rows.Scan(&id, &submittedAt, &questionID, &questionKey, &answer, &respondentID, &presentedOrder, &test); err != nil {
+			return nil, fmt.Errorf("failed to read responses: %w", err)
+		}
+		if n := len(responses); n == 0 || responses[n-1].ID != id {
+			responses = append(responses, Response{
+				ID:             id,
+				SubmittedAt:    submittedAt,
+				Answers:        make(map[string]json.RawMessage),
+				Keys:           make(map[string]string),
+				PresentedOrder: make(map[string][]string),
+				Test:           test,
+			})
+		}
+		response := &responses[len(responses)-1]
+		if respondentID != "" {
+			response.RespondentID = respondentID
+		}
+		if answer != nil {
+			response.Answers[questionID] = answer
+		}
+		if questionKey != "" {
+			response.Keys[questionID] = questionKey
+		}
+		if presentedOrder != nil {
+			var keys []string
+			if err := json.Unmarshal(presentedOrder, &keys); err != nil {
+				return nil, fmt.Errorf("failed to read responses: presented order of %s: %w", questionID, err)
+			}
+			response.PresentedOrder[questionID] = keys
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read responses: %w", err)
+	}
+	return responses, nil
+}
+
+// CountResponses counts the distinct responses to the forms by the time
+// they were first submitted, so edits are not counted again. Test
+// submissions don't count against quotas.
+func (p *Projection) CountResponses(ctx context.Context, formIDs []string, start, end time.Time) (int64, error) {
+	if p.db == nil {
+		return 0, ErrNotConfigured
+	}
+	if len(formIDs) == 0 {
+		return 0, nil
+	}
+
+	var count int64
+	err := p.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM (
+			SELECT response_id FROM response_events
+			WHERE form_id = ANY($1) AND NOT is_test
+			GROUP BY form_id, response_id
+			HAVING MIN(submitted_at) >= $2 AND MIN(submitted_at) < $3
+		) responses`, formIDs, start.UTC(), end.UTC()).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count responses: %w", err)
+	}
+	return count, nil
+}
+
+// FormTimings reads the timings of the latest submissions of a form. Timings
+// are kept as submitted, edits aside.
+func (p *Projection) FormTimings(ctx context.Context, formID string, limit int) ([]map[string]QuestionTiming, int64, error) {
+	if p.db == nil {
+		return nil, 0, ErrNotConfigured
+	}
+
+	var responses int64
+	err := p.db.QueryRowContext(ctx, `
+		SELECT COUNT(DISTINCT response_id) FROM response_events
+		WHERE form_id = $1 AND NOT is_test`, formID).Scan(&responses)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count responses: %w", err)
+	}
+
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT timings FROM response_timings
+		WHERE form_id = $1 AND NOT is_test
+		ORDER BY submitted_at DESC
+		LIMIT $2`, formID, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read timings: %w", err)
+	}
+	defer rows.Close()
+
+	var timings []map[string]QuestionTiming
+	for rows.Next() {
+		var encoded []byte
+		if err := rows.Scan(&encoded); err != nil {
+			return nil, 0, fmt.Errorf("failed to read timings: %w", err)
+		}
+		var timing map[string]QuestionTiming
+		if err := json.Unmarshal(encoded, &timing); err != nil {
+			return nil, 0, fmt.Errorf("failed to read timings: %w", err)
+		}
+		timings = append(timings, timing)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read timings: %w", err)
+	}
+	return timings, responses, nil
+}
+
+// ResponseTimings reads the timings of responses of a form by ID
+func (p *Projection) ResponseTimings(ctx context.Context, formID string, responseIDs []string) (map[string]map[string]QuestionTiming, error) {
+	if p.db == nil {
+		return nil, ErrNotConfigured
+	}
+
+	timings := make(map[string]map[string]QuestionTiming)
+	if len(responseIDs) == 0 {
+		return timings, nil
+	}
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT response_id, timings FROM response_timings
+		WHERE form_id = $1 AND response_id = ANY($2)`, formID, responseIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read timings: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id      string
+			encoded []byte
+		)
+		if err := rows.Scan(&id, &encoded); err != nil {
+			return nil, fmt.Errorf("failed to read timings: %w", err)
+		}
+		var timing map[string]QuestionTiming
+		if err := json.Unmarshal(encoded, &timing); err != nil {
+			return nil, fmt.Errorf("failed to read timings of %s: %w", id, err)
+		}
+		timings[id] = timing
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read timings: %w", err)
+	}
+	return timings, nil
+}
+
+// FunnelDays reads the daily funnel counts of a form. The event bus counts
+// the sessions of each day as their beacons and submissions are projected.
+func (p *Projection) FunnelDays(ctx context.Context, formID string, from, to time.Time) ([]FunnelDay, error) {
+	if p.db == nil {
+		return nil, ErrNotConfigured
+	}
+
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT day, views, starts, submissions FROM form_funnel_daily
+		WHERE form_id = $1 AND day BETWEEN $2 AND $3
+		ORDER BY day`, formID, from.Format(funnelDayLayout), to.Format(funnelDayLayout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read funnel: %w", err)
+	}
+	defer rows.Close()
+
+	var days []FunnelDay
+	for rows.Next() {
+		var (
+			day   FunnelDay
+			value time.Time
+		)
+		if err := rows.Scan(&value, &day.Views, &day.Starts, &day.Submissions); err != nil {
+			return nil, fmt.Errorf("failed to read funnel: %w", err)
+		}
+		day.Date = value.Format(funnelDayLayout)
+		days = append(days, day)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read funnel: %w", err)
+	}
+	return days, nil
+}
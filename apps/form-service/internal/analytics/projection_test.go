@@ -0,0 +1,138 @@
+package analytics
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// testProjection creates the response_events table in a new schema of
+// FORM_SERVICE_TEST_DATABASE_URL, dropped when the test ends
+func testProjection(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("FORM_SERVICE_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("FORM_SERVICE_TEST_DATABASE_URL not set")
+	}
+
+	admin, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := fmt.Sprintf("analytics_test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		admin.Close()
+	})
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+
+	db, err := sql.Open("pgx", u.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	// The columns the event bus projects that the queries read
+	if _, err := db.Exec(`
+		CREATE TABLE response_events (
+			event_id VARCHAR(255) NOT NULL,
+			question_id VARCHAR(255) NOT NULL,
+			question_key VARCHAR(64),
+			response_id VARCHAR(255) NOT NULL,
+			form_id VARCHAR(255) NOT NULL,
+			revision INTEGER NOT NULL DEFAULT 1,
+			answer JSONB,
+			respondent_id VARCHAR(255),
+			is_anonymous BOOLEAN NOT NULL DEFAULT FALSE,
+			submitted_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			presented_order JSONB,
+			is_test BOOLEAN NOT NULL DEFAULT FALSE,
+			PRIMARY KEY (event_id, question_id)
+		)`); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestProjectionLeavesOutTestSubmissions(t *testing.T) {
+	db := testProjection(t)
+	ctx := context.Background()
+	submitted := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	insert := func(responseID, drink string, test bool) {
+		t.Helper()
+		if _, err := db.Exec(`
+			INSERT INTO response_events (event_id, question_id, response_id, form_id, answer, submitted_at, is_test)
+			VALUES ($1, 'q-1', $2, 'form-1', $3, $4, $5)`,
+			"e-"+responseID, responseID, fmt.Sprintf("%q", drink), submitted, test); err != nil {
+			t.Fatal(err)
+		}
+	}
+	insert("r-1", "tea", false)
+	insert("r-2", "tea", false)
+	insert("r-3", "coffee", false)
+
+	projection := NewProjection(db)
+	counts := func() (*QueryResult, int, int64) {
+		t.Helper()
+		result, err := projection.Query(ctx, "form-1", &Query{Question: "q-1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		distinct, err := projection.DistinctAnswers(ctx, "form-1", QuestionRef{ID: "q-1"}, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		count, err := projection.CountResponses(ctx, []string{"form-1"}, submitted, submitted.Add(time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result, distinct, count
+	}
+	before, distinctBefore, countBefore := counts()
+
+	// Owners testing the form answer with what real respondents don't
+	insert("r-test-1", "tea", true)
+	insert("r-test-2", "water", true)
+
+	after, distinctAfter, countAfter := counts()
+	beforeJSON, _ := json.Marshal(before)
+	afterJSON, _ := json.Marshal(after)
+	if string(afterJSON) != string(beforeJSON) {
+		t.Errorf("query result with test submissions = %s, want %s", afterJSON, beforeJSON)
+	}
+	if after.Responses != 3 || distinctAfter != distinctBefore || countAfter != countBefore || countAfter != 3 {
+		t.Errorf("counts = %d responses, %d answers, %d counted, want 3, %d and 3", after.Responses, distinctAfter, countAfter, distinctBefore)
+	}
+
+	// Exports only read test submissions when asked to
+	responses, err := projection.Responses(ctx, "form-1", ResponseCursor{}, 10, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 3 {
+		t.Errorf("read %d responses, want the 3 real ones", len(responses))
+	}
+	responses, err = projection.Responses(ctx, "form-1", ResponseCursor{}, 10, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 5 || !responses[3].Test || !responses[4].Test || responses[0].Test {
+		t.Errorf("read %+v, want the 5 responses with the last 2 flagged as test", responses)
+	}
+}
@@ -0,0 +1,379 @@
+package analytics
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Filter operators of analytics queries
+const (
+	OpEq       = "eq"
+	OpNeq      = "neq"
+	OpIn       = "in"
+	OpNotIn    = "not_in"
+	OpContains = "contains"
+	OpGt       = "gt"
+	OpGte      = "gte"
+	OpLt       = "lt"
+	OpLte      = "lte"
+)
+
+// Time buckets analytics queries group by
+const (
+	BucketHour  = "hour"
+	BucketDay   = "day"
+	BucketWeek  = "week"
+	BucketMonth = "month"
+)
+
+const (
+	// MaxQueryFilters bounds the filters of a query
+	MaxQueryFilters = 10
+	// MaxQueryValues bounds the values of in and not_in filters
+	MaxQueryValues = 100
+	// MaxQueryGroups bounds the rows of a query result, one per group and
+	// answer
+	MaxQueryGroups = 1000
+)
+
+// ErrInvalidQuery is returned for analytics queries that can't be run
+var ErrInvalidQuery = errors.New("invalid analytics query")
+
+// Query counts the answers to a question of a form, among the responses
+// matching every filter, in groups
+type Query struct {
+	// Question is the ID or key of the question whose answers are counted
+	Question string   `json:"question" binding:"required"`
+	Filters  []Filter `json:"filters,omitempty"`
+	// From and To bound the submission time of the responses counted, To
+	// excluded
+	From    *time.Time `json:"from,omitempty" example:"2024-03-01T00:00:00Z"`
+	To      *time.Time `json:"to,omitempty" example:"2024-04-01T00:00:00Z"`
+	GroupBy *GroupBy   `json:"group_by,omitempty"`
+
+	// Refs are the questions of the query as resolved by the caller, by
+	// question as the query refers to them. The questions missing are read
+	// by ID alone.
+	Refs map[string]QuestionRef `json:"-"`
+}
+
+// QuestionRef is a question whose answers a query reads: those projected
+// under its ID, and those projected under any of its keys, current or
+// former, whatever question they answered
+type QuestionRef struct {
+	ID   string
+	Keys []string
+}
+
+// ref returns the question the query refers to as question
+func (q *Query) ref(question string) QuestionRef {
+	if ref, ok := q.Refs[question]; ok {
+		return ref
+	}
+	return QuestionRef{ID: question}
+}
+
+// Filter keeps the responses whose answer to a question compares to Value.
+// eq and neq compare answers as they are; in and not_in take an array of
+// values; contains takes a value or an array of values all chosen in a
+// multiple choice answer; gt, gte, lt and lte take a number.
+type Filter struct {
+	Question string          `json:"question"`
+	Operator string          `json:"operator" enums:"eq,neq,in,not_in,contains,gt,gte,lt,lte"`
+	Value    json.RawMessage `json:"value" swaggertype:"object"`
+}
+
+// GroupBy groups responses by their answer to another question, or by the
+// UTC time bucket they were submitted in. Exactly one is set.
+type GroupBy struct {
+	Question   string `json:"question,omitempty"`
+	TimeBucket string `json:"time_bucket,omitempty" enums:"hour,day,week,month"`
+}
+
+// QueryResult is the outcome of a query
+type QueryResult struct {
+	FormID   string `json:"form_id"`
+	Question string `json:"question"`
+	// Responses is the number of responses matching the filters that
+	// answered the question
+	Responses int          `json:"responses"`
+	Groups    []QueryGroup `json:"groups"`
+	// Truncated is set when the result was cut at MaxQueryGroups rows
+	Truncated bool `json:"truncated"`
+}
+
+// QueryGroup counts the answers of the responses of a group
+type QueryGroup struct {
+	// Key is the answer to the group-by question or the start of the time
+	// bucket; null without group_by and for the responses not answering the
+	// group-by question
+	Key       json.RawMessage `json:"key" swaggertype:"object"`
+	Responses int             `json:"responses"`
+	Answers   []AnswerCount   `json:"answers"`
+}
+
+// AnswerCount is how many responses of a group chose an answer
+type AnswerCount struct {
+	Answer json.RawMessage `json:"answer" swaggertype:"object"`
+	Count  int             `json:"count"`
+	// Percentage is of the responses of the group. The percentages of a
+	// multiple choice question may add up to more than 100.
+	Percentage float64 `json:"percentage" example:"42.5"`
+}
+
+// TableRow is a group and answer pair of a result, flattened for tables
+type TableRow struct {
+	Key            json.RawMessage `json:"key"`
+	GroupResponses int             `json:"group_responses"`
+	Answer         json.RawMessage `json:"answer"`
+	Count          int             `json:"count"`
+	Percentage     float64         `json:"percentage"`
+}
+
+// Rows flattens the result into a row per group and answer, in order
+func (r *QueryResult) Rows() []TableRow {
+	var rows []TableRow
+	for _, group := range r.Groups {
+		for _, answer := range group.Answers {
+			rows = append(rows, TableRow{
+				Key:            group.Key,
+				GroupResponses: group.Responses,
+				Answer:         answer.Answer,
+				Count:          answer.Count,
+				Percentage:     answer.Percentage,
+			})
+		}
+	}
+	return rows
+}
+
+// Questions returns the IDs of the questions the query refers to, the
+// counted one first
+func (q *Query) Questions() []string {
+	ids := []string{q.Question}
+	for _, filter := range q.Filters {
+		ids = append(ids, filter.Question)
+	}
+	if q.GroupBy != nil && q.GroupBy.Question != "" {
+		ids = append(ids, q.GroupBy.Question)
+	}
+	return ids
+}
+
+// Validate checks the query is well formed
+func (q *Query) Validate() error {
+	if q.Question == "" {
+		return fmt.Errorf("%w: question is required", ErrInvalidQuery)
+	}
+	if len(q.Filters) > MaxQueryFilters {
+		return fmt.Errorf("%w: at most %d filters are allowed", ErrInvalidQuery, MaxQueryFilters)
+	}
+	for i, filter := range q.Filters {
+		if err := filter.validate(); err != nil {
+			return fmt.Errorf("%w: filter %d: %v", ErrInvalidQuery, i, err)
+		}
+	}
+	if q.From != nil && q.To != nil && !q.From.Before(*q.To) {
+		return fmt.Errorf("%w: from must be before to", ErrInvalidQuery)
+	}
+	if g := q.GroupBy; g != nil {
+		switch {
+		case (g.Question == "") == (g.TimeBucket == ""):
+			return fmt.Errorf("%w: group_by takes either a question or a time_bucket", ErrInvalidQuery)
+		case g.TimeBucket != "" && !isBucket(g.TimeBucket):
+			return fmt.Errorf("%w: time_bucket must be hour, day, week or month", ErrInvalidQuery)
+		}
+	}
+	return nil
+}
+
+func (f *Filter) validate() error {
+	if f.Question == "" {
+		return errors.New("question is required")
+	}
+	value := bytes.TrimSpace(f.Value)
+	if len(value) == 0 || bytes.Equal(value, []byte("null")) {
+		return errors.New("value is required")
+	}
+	if !json.Valid(value) {
+		return errors.New("value is not JSON")
+	}
+
+	switch f.Operator {
+	case OpEq, OpNeq, OpContains:
+	case OpIn, OpNotIn:
+		var values []json.RawMessage
+		if err := json.Unmarshal(value, &values); err != nil || len(values) == 0 {
+			return fmt.Errorf("%s takes a non-empty array", f.Operator)
+		}
+		if len(values) > MaxQueryValues {
+			return fmt.Errorf("%s takes at most %d values", f.Operator, MaxQueryValues)
+		}
+	case OpGt, OpGte, OpLt, OpLte:
+		var number float64
+		if err := json.Unmarshal(value, &number); err != nil {
+			return fmt.Errorf("%s takes a number", f.Operator)
+		}
+	default:
+		return fmt.Errorf("unknown operator %q", f.Operator)
+	}
+	return nil
+}
+
+func isBucket(bucket string) bool {
+	switch bucket {
+	case BucketHour, BucketDay, BucketWeek, BucketMonth:
+		return true
+	}
+	return false
+}
+
+// comparisons are the SQL comparisons of the numeric operators
+var comparisons = map[string]string{OpGt: ">", OpGte: ">=", OpLt: "<", OpLte: "<="}
+
+// answerElements expands an answer into one row per value, the values of
+// multiple choice answers and any other answer as is
+const answerElements = "jsonb_array_elements(CASE jsonb_typeof(%[1]s) WHEN 'array' THEN %[1]s ELSE jsonb_build_array(%[1]s) END)"
+
+// BuildSQL builds the single statement running a validated query over the
+// response_events projection, with its arguments. Every value is a bind
+// parameter. The statement returns one row per group and answer, at most
+// MaxQueryGroups + 1 so that truncation shows, with the responses of the
+// group and of the whole query. Test submissions are not counted.
+func (q *Query) BuildSQL(formID string) (string, []interface{}) {
+	args := []interface{}{formID}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	var where strings.Builder
+	fmt.Fprintf(&where, "t.form_id = $1 AND %s AND NOT t.is_test", q.ref(q.Question).condition("t", arg))
+	if q.From != nil {
+		fmt.Fprintf(&where, " AND t.submitted_at >= %s", arg(q.From.UTC()))
+	}
+	if q.To != nil {
+		fmt.Fprintf(&where, " AND t.submitted_at < %s", arg(q.To.UTC()))
+	}
+	for _, filter := range q.Filters {
+		fmt.Fprintf(&where, "\n\t\t\tAND EXISTS (SELECT 1 FROM response_events f WHERE f.form_id = t.form_id AND f.response_id = t.response_id AND %s AND %s)",
+			q.ref(filter.Question).condition("f", arg), filter.condition(arg))
+	}
+
+	// Without grouping every response is in the null group
+	key, join := "'null'::jsonb", ""
+	if g := q.GroupBy; g != nil && g.TimeBucket != "" {
+		key = fmt.Sprintf(`to_jsonb(to_char(date_trunc(%s, m.submitted_at AT TIME ZONE 'UTC'), 'YYYY-MM-DD"T"HH24:MI:SS"Z"'))`, arg(g.TimeBucket))
+	} else if g != nil {
+		key = "gv.value"
+		join = fmt.Sprintf(`
+			LEFT JOIN response_events g ON g.form_id = $1 AND g.response_id = m.response_id AND %s
+			LEFT JOIN LATERAL %s AS gv(value) ON TRUE`, q.ref(g.Question).condition("g", arg), fmt.Sprintf(answerElements, "g.answer"))
+	}
+
+	query := fmt.Sprintf(`
+		WITH matched AS (
+			SELECT t.response_id, t.answer, t.submitted_at
+			FROM response_events t
+			WHERE %s
+		),
+		grouped AS (
+			SELECT m.response_id, COALESCE(%s, 'null'::jsonb) AS grp, m.answer
+			FROM matched m%s
+		),
+		totals AS (
+			SELECT grp, COUNT(DISTINCT response_id) AS responses FROM grouped GROUP BY grp
+		),
+		cells AS (
+			SELECT grp, v.value AS answer, COUNT(*) AS count
+			FROM grouped, %s AS v(value)
+			GROUP BY grp, v.value
+		)
+		SELECT c.grp, c.answer, c.count, t.responses, (SELECT COUNT(*) FROM matched) AS matched
+		FROM cells c JOIN totals t ON t.grp = c.grp
+		ORDER BY c.grp, c.count DESC, c.answer
+		LIMIT %d`, where.String(), key, join, fmt.Sprintf(answerElements, "grouped.answer"), MaxQueryGroups+1)
+	return query, args
+}
+
+// condition is the SQL condition matching the answers to the question in
+// the rows of table
+func (r QuestionRef) condition(table string, arg func(interface{}) string) string {
+	if len(r.Keys) == 0 {
+		return fmt.Sprintf("%s.question_id = %s", table, arg(r.ID))
+	}
+	return fmt.Sprintf("(%[1]s.question_id = %[2]s OR %[1]s.question_key = ANY(%[3]s))", table, arg(r.ID), arg(r.Keys))
+}
+
+// condition is the SQL condition of a validated filter on the answer f.answer
+func (f *Filter) condition(arg func(interface{}) string) string {
+	value := string(bytes.TrimSpace(f.Value))
+	switch f.Operator {
+	case OpEq:
+		return fmt.Sprintf("f.answer = %s::jsonb", arg(value))
+	case OpNeq:
+		return fmt.Sprintf("f.answer <> %s::jsonb", arg(value))
+	case OpIn:
+		return fmt.Sprintf("f.answer IN (SELECT jsonb_array_elements(%s::jsonb))", arg(value))
+	case OpNotIn:
+		return fmt.Sprintf("f.answer NOT IN (SELECT jsonb_array_elements(%s::jsonb))", arg(value))
+	case OpContains:
+		if !strings.HasPrefix(value, "[") {
+			value = "[" + value + "]"
+		}
+		return fmt.Sprintf("jsonb_typeof(f.answer) = 'array' AND f.answer @> %s::jsonb", arg(value))
+	default:
+		// The cast only applies to numbers, whatever order the conditions
+		// are evaluated in
+		var number float64
+		json.Unmarshal([]byte(value), &number)
+		return fmt.Sprintf("CASE WHEN jsonb_typeof(f.answer) = 'number' THEN (f.answer)::numeric END %s %s", comparisons[f.Operator], arg(number))
+	}
+}
+
+// QueryRow is a row of the statement of BuildSQL
+type QueryRow struct {
+	Group          json.RawMessage
+	Answer         json.RawMessage
+	Count          int
+	GroupResponses int
+	Responses      int
+}
+
+// Assemble builds the result of a query from the rows of its statement, in
+// their order
+func Assemble(formID, question string, rows []QueryRow) *QueryResult {
+	result := &QueryResult{FormID: formID, Question: question, Groups: []QueryGroup{}}
+	if len(rows) > MaxQueryGroups {
+		rows = rows[:MaxQueryGroups]
+		result.Truncated = true
+	}
+	for _, row := range rows {
+		result.Responses = row.Responses
+		last := len(result.Groups) - 1
+		if last < 0 || !bytes.Equal(result.Groups[last].Key, row.Group) {
+			result.Groups = append(result.Groups, QueryGroup{Key: row.Group, Responses: row.GroupResponses})
+			last++
+		}
+		group := &result.Groups[last]
+		group.Answers = append(group.Answers, AnswerCount{
+			Answer:     row.Answer,
+			Count:      row.Count,
+			Percentage: percentage(row.Count, row.GroupResponses),
+		})
+	}
+	return result
+}
+
+// percentage is part of total in percent, rounded to two decimals
+func percentage(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)*10000/float64(total)) / 100
+}
@@ -0,0 +1,242 @@
+package analytics
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFilterConditions(t *testing.T) {
+	tests := []struct {
+		operator, value string
+		condition       string
+		arg             interface{}
+	}{
+		{OpEq, `"tea"`, "f.answer = $4::jsonb", `"tea"`},
+		{OpNeq, ` "tea" `, "f.answer <> $4::jsonb", `"tea"`},
+		{OpIn, `["tea","coffee"]`, "f.answer IN (SELECT jsonb_array_elements($4::jsonb))", `["tea","coffee"]`},
+		{OpNotIn, `["tea"]`, "f.answer NOT IN (SELECT jsonb_array_elements($4::jsonb))", `["tea"]`},
+		{OpContains, `"nuts"`, "jsonb_typeof(f.answer) = 'array' AND f.answer @> $4::jsonb", `["nuts"]`},
+		{OpContains, `["nuts","gluten"]`, "jsonb_typeof(f.answer) = 'array' AND f.answer @> $4::jsonb", `["nuts","gluten"]`},
+		{OpGt, `18`, "CASE WHEN jsonb_typeof(f.answer) = 'number' THEN (f.answer)::numeric END > $4", 18.0},
+		{OpGte, `18.5`, "CASE WHEN jsonb_typeof(f.answer) = 'number' THEN (f.answer)::numeric END >= $4", 18.5},
+		{OpLt, `-1`, "CASE WHEN jsonb_typeof(f.answer) = 'number' THEN (f.answer)::numeric END < $4", -1.0},
+		{OpLte, `0`, "CASE WHEN jsonb_typeof(f.answer) = 'number' THEN (f.answer)::numeric END <= $4", 0.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.operator+" "+tt.value, func(t *testing.T) {
+			query := Query{Question: "q-1", Filters: []Filter{{Question: "q-2", Operator: tt.operator, Value: json.RawMessage(tt.value)}}}
+			if err := query.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			statement, args := query.BuildSQL("form-1")
+			want := "f.question_id = $3 AND " + tt.condition + ")"
+			if !strings.Contains(statement, want) {
+				t.Errorf("statement lacks %q:\n%s", want, statement)
+			}
+			if wantArgs := []interface{}{"form-1", "q-1", "q-2", tt.arg}; !reflect.DeepEqual(args, wantArgs) {
+				t.Errorf("args = %#v, want %#v", args, wantArgs)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	filter := func(operator, value string) []Filter {
+		return []Filter{{Question: "q-2", Operator: operator, Value: json.RawMessage(value)}}
+	}
+	manyValues := "[" + strings.TrimSuffix(strings.Repeat(`"a",`, MaxQueryValues+1), ",") + "]"
+
+	tests := []struct {
+		name  string
+		query Query
+		want  string
+	}{
+		{"valid", Query{Question: "q-1", Filters: filter(OpIn, `["a"]`), From: &from, To: &to, GroupBy: &GroupBy{TimeBucket: BucketWeek}}, ""},
+		{"no question", Query{}, "question is required"},
+		{"too many filters", Query{Question: "q-1", Filters: make([]Filter, MaxQueryFilters+1)}, "at most 10 filters"},
+		{"filter without question", Query{Question: "q-1", Filters: []Filter{{Operator: OpEq, Value: json.RawMessage(`1`)}}}, "question is required"},
+		{"unknown operator", Query{Question: "q-1", Filters: filter("like", `"a%"`)}, `unknown operator "like"`},
+		{"missing value", Query{Question: "q-1", Filters: filter(OpEq, ``)}, "value is required"},
+		{"null value", Query{Question: "q-1", Filters: filter(OpEq, `null`)}, "value is required"},
+		{"in with a scalar", Query{Question: "q-1", Filters: filter(OpIn, `"a"`)}, "in takes a non-empty array"},
+		{"not_in with an empty array", Query{Question: "q-1", Filters: filter(OpNotIn, `[]`)}, "not_in takes a non-empty array"},
+		{"in with too many values", Query{Question: "q-1", Filters: filter(OpIn, manyValues)}, "at most 100 values"},
+		{"gt with a string", Query{Question: "q-1", Filters: filter(OpGt, `"18"`)}, "gt takes a number"},
+		{"empty range", Query{Question: "q-1", From: &to, To: &from}, "from must be before to"},
+		{"empty group_by", Query{Question: "q-1", GroupBy: &GroupBy{}}, "either a question or a time_bucket"},
+		{"double group_by", Query{Question: "q-1", GroupBy: &GroupBy{Question: "q-2", TimeBucket: BucketDay}}, "either a question or a time_bucket"},
+		{"unknown bucket", Query{Question: "q-1", GroupBy: &GroupBy{TimeBucket: "year"}}, "time_bucket must be"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.query.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("err = %v, want none", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidQuery) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want ErrInvalidQuery mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildSQLGrouping(t *testing.T) {
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.FixedZone("CET", 3600))
+	tests := []struct {
+		name     string
+		query    Query
+		contains []string
+		args     []interface{}
+	}{
+		{
+			name:     "ungrouped",
+			query:    Query{Question: "q-1"},
+			contains: []string{"COALESCE('null'::jsonb, 'null'::jsonb) AS grp", "FROM matched m\n", "t.question_id = $2 AND NOT t.is_test"},
+			args:     []interface{}{"form-1", "q-1"},
+		},
+		{
+			name:     "by time bucket",
+			query:    Query{Question: "q-1", From: &from, GroupBy: &GroupBy{TimeBucket: BucketDay}},
+			contains: []string{"t.submitted_at >= $3", "date_trunc($4, m.submitted_at AT TIME ZONE 'UTC')"},
+			args:     []interface{}{"form-1", "q-1", from.UTC(), BucketDay},
+		},
+		{
+			name:  "by question",
+			query: Query{Question: "q-1", GroupBy: &GroupBy{Question: "q-2"}},
+			contains: []string{
+				"COALESCE(gv.value, 'null'::jsonb) AS grp",
+				"LEFT JOIN response_events g ON g.form_id = $1 AND g.response_id = m.response_id AND g.question_id = $3",
+				"LEFT JOIN LATERAL jsonb_array_elements(CASE jsonb_typeof(g.answer)",
+			},
+			args: []interface{}{"form-1", "q-1", "q-2"},
+		},
+		{
+			name: "by key",
+			query: Query{Question: "plan", GroupBy: &GroupBy{Question: "q-2"}, Refs: map[string]QuestionRef{
+				"plan": {ID: "q-1", Keys: []string{"plan", "tier"}},
+			}},
+			contains: []string{
+				"(t.question_id = $2 OR t.question_key = ANY($3)) AND NOT t.is_test",
+				"g.response_id = m.response_id AND g.question_id = $4",
+			},
+			args: []interface{}{"form-1", "q-1", []string{"plan", "tier"}, "q-2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statement, args := tt.query.BuildSQL("form-1")
+			for _, want := range tt.contains {
+				if !strings.Contains(statement, want) {
+					t.Errorf("statement lacks %q:\n%s", want, statement)
+				}
+			}
+			if !strings.Contains(statement, fmt.Sprintf("LIMIT %d", MaxQueryGroups+1)) {
+				t.Errorf("statement is not limited to %d rows:\n%s", MaxQueryGroups+1, statement)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %#v, want %#v", args, tt.args)
+			}
+		})
+	}
+}
+
+func TestAssemble(t *testing.T) {
+	row := func(group, answer string, count, groupResponses int) QueryRow {
+		return QueryRow{Group: json.RawMessage(group), Answer: json.RawMessage(answer), Count: count, GroupResponses: groupResponses, Responses: 10}
+	}
+	type answer struct {
+		answer     string
+		count      int
+		percentage float64
+	}
+	type group struct {
+		key       string
+		responses int
+		answers   []answer
+	}
+
+	tests := []struct {
+		name      string
+		rows      []QueryRow
+		responses int
+		groups    []group
+	}{
+		{"no responses", nil, 0, nil},
+		{
+			name:      "ungrouped",
+			rows:      []QueryRow{row(`null`, `"tea"`, 7, 10), row(`null`, `"coffee"`, 3, 10)},
+			responses: 10,
+			groups:    []group{{`null`, 10, []answer{{`"tea"`, 7, 70}, {`"coffee"`, 3, 30}}}},
+		},
+		{
+			// Multiple choice answers count once per value chosen
+			name:      "multiple choice",
+			rows:      []QueryRow{row(`null`, `"nuts"`, 6, 10), row(`null`, `"gluten"`, 5, 10)},
+			responses: 10,
+			groups:    []group{{`null`, 10, []answer{{`"nuts"`, 6, 60}, {`"gluten"`, 5, 50}}}},
+		},
+		{
+			name: "grouped",
+			rows: []QueryRow{
+				row(`"a"`, `"tea"`, 2, 3), row(`"a"`, `"coffee"`, 1, 3),
+				row(`"b"`, `"coffee"`, 6, 6),
+				row(`null`, `"tea"`, 1, 1),
+			},
+			responses: 10,
+			groups: []group{
+				{`"a"`, 3, []answer{{`"tea"`, 2, 66.67}, {`"coffee"`, 1, 33.33}}},
+				{`"b"`, 6, []answer{{`"coffee"`, 6, 100}}},
+				{`null`, 1, []answer{{`"tea"`, 1, 100}}},
+			},
+		},
+		{
+			name:      "empty group",
+			rows:      []QueryRow{row(`"a"`, `"tea"`, 0, 0)},
+			responses: 10,
+			groups:    []group{{`"a"`, 0, []answer{{`"tea"`, 0, 0}}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Assemble("form-1", "q-1", tt.rows)
+			if result.FormID != "form-1" || result.Question != "q-1" || result.Truncated {
+				t.Errorf("result = %+v, want form-1, q-1, not truncated", result)
+			}
+			if result.Responses != tt.responses {
+				t.Errorf("responses = %d, want %d", result.Responses, tt.responses)
+			}
+			var got []group
+			for _, g := range result.Groups {
+				gotGroup := group{string(g.Key), g.Responses, nil}
+				for _, a := range g.Answers {
+					gotGroup.answers = append(gotGroup.answers, answer{string(a.Answer), a.Count, a.Percentage})
+				}
+				got = append(got, gotGroup)
+			}
+			if !reflect.DeepEqual(got, tt.groups) {
+				t.Errorf("groups = %+v, want %+v", got, tt.groups)
+			}
+		})
+	}
+}
+
+func TestAssembleTruncates(t *testing.T) {
+	rows := make([]QueryRow, MaxQueryGroups+1)
+	for i := range rows {
+		rows[i] = QueryRow{Group: json.RawMessage(fmt.Sprint(i)), Answer: json.RawMessage(`"tea"`), Count: 1, GroupResponses: 1, Responses: len(rows)}
+	}
+	result := Assemble("form-1", "q-1", rows)
+	if !result.Truncated || len(result.Groups) != MaxQueryGroups {
+		t.Errorf("%d groups, truncated %v, want %d and truncated", len(result.Groups), result.Truncated, MaxQueryGroups)
+	}
+}
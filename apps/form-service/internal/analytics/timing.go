@@ -0,0 +1,138 @@
+package analytics
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+)
+
+// QuestionTiming is how long a question held focus while a respondent
+// filled the form, and how often they returned to it, as reported by the
+// timing beacons of their client and capped by the projection
+type QuestionTiming struct {
+	FocusMs  int64 `json:"focus_ms"`
+	Revisits int   `json:"revisits"`
+}
+
+// TimingReader reads the timing beacons of the submissions of forms from
+// the response_timings projection
+type TimingReader interface {
+	// FormTimings returns the timings of up to limit of the latest
+	// submissions of a form and the number of its responses, test
+	// submissions aside
+	FormTimings(ctx context.Context, formID string, limit int) ([]map[string]QuestionTiming, int64, error)
+}
+
+// TimingReport is the time respondents spend on each question of a form,
+// and where those who abandon it stop
+type TimingReport struct {
+	FormID string `json:"form_id"`
+	// Responses counts the responses of the form, and Timed the latest of
+	// them whose timings were sampled
+	Responses int64 `json:"responses" example:"1250"`
+	Timed     int   `json:"timed" example:"1180"`
+	// AbandonedDrafts counts the drafts left idle for longer than the
+	// abandonment threshold, whose last answered question they stopped at
+	AbandonedDrafts int                   `json:"abandoned_drafts" example:"85"`
+	AbandonedAfter  string                `json:"abandoned_after" example:"24h0m0s"`
+	Questions       []QuestionTimingStats `json:"questions"`
+	GeneratedAt     time.Time             `json:"generated_at"`
+} // @name TimingReport
+
+// QuestionTimingStats is the timing and drop-off of a question
+type QuestionTimingStats struct {
+	QuestionID string `json:"question_id"`
+	Title      string `json:"title,omitempty"`
+	// Samples counts the timed responses which timed the question
+	Samples  int     `json:"samples" example:"1170"`
+	MedianMs float64 `json:"median_ms" example:"8400"`
+	P90Ms    float64 `json:"p90_ms" example:"21500"`
+	// MeanRevisits is the average number of times respondents returned to
+	// the question
+	MeanRevisits float64 `json:"mean_revisits" example:"0.3"`
+	// Reached counts the respondents who answered the question or a later
+	// one: the responses, and the abandoned drafts stopping there or later
+	Reached int64 `json:"reached" example:"1300"`
+	// DroppedOff counts the abandoned drafts whose last answered question
+	// it is, and DropOffRate their share of Reached
+	DroppedOff  int     `json:"dropped_off" example:"12"`
+	DropOffRate float64 `json:"drop_off_rate" example:"0.0092"`
+} // @name QuestionTimingStats
+
+// AggregateTimings builds the timing report of the questions of a form, in
+// form order. timings are those of the sampled submissions and responses
+// the number of responses; abandoned are the question IDs answered by each
+// abandoned draft. Responses count as having reached every question.
+func AggregateTimings(questionIDs []string, timings []map[string]QuestionTiming, responses int64, abandoned [][]string) []QuestionTimingStats {
+	position := make(map[string]int, len(questionIDs))
+	for i, id := range questionIDs {
+		position[id] = i
+	}
+
+	// A draft stops at the last question it answered in form order;
+	// drafts answering none of the current questions are left out
+	stoppedAt := make([]int, len(questionIDs))
+	for _, answered := range abandoned {
+		last := -1
+		for _, id := range answered {
+			if i, ok := position[id]; ok && i > last {
+				last = i
+			}
+		}
+		if last >= 0 {
+			stoppedAt[last]++
+		}
+	}
+
+	stats := make([]QuestionTimingStats, len(questionIDs))
+	// Drafts stopping at or after a question reached it
+	var later int64
+	for i := len(questionIDs) - 1; i >= 0; i-- {
+		later += int64(stoppedAt[i])
+		stats[i] = QuestionTimingStats{
+			QuestionID: questionIDs[i],
+			Reached:    responses + later,
+			DroppedOff: stoppedAt[i],
+		}
+		if stats[i].Reached > 0 {
+			stats[i].DropOffRate = float64(stoppedAt[i]) / float64(stats[i].Reached)
+		}
+	}
+
+	focus := make([][]int64, len(questionIDs))
+	revisits := make([]int, len(questionIDs))
+	for _, response := range timings {
+		for id, timing := range response {
+			if i, ok := position[id]; ok {
+				focus[i] = append(focus[i], timing.FocusMs)
+				revisits[i] += timing.Revisits
+			}
+		}
+	}
+	for i := range stats {
+		samples := focus[i]
+		if len(samples) == 0 {
+			continue
+		}
+		sort.Slice(samples, func(a, b int) bool { return samples[a] < samples[b] })
+		stats[i].Samples = len(samples)
+		stats[i].MedianMs = Percentile(samples, 0.5)
+		stats[i].P90Ms = Percentile(samples, 0.9)
+		stats[i].MeanRevisits = float64(revisits[i]) / float64(len(samples))
+	}
+	return stats
+}
+
+// Percentile returns the p-th percentile, 0 <= p <= 1, of sorted values,
+// interpolating linearly between the closest ranks as PostgreSQL's
+// percentile_cont does. It is 0 without values.
+func Percentile(sorted []int64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := p * float64(len(sorted)-1)
+	lower, upper := int(math.Floor(rank)), int(math.Ceil(rank))
+	fraction := rank - float64(lower)
+	return float64(sorted[lower]) + fraction*float64(sorted[upper]-sorted[lower])
+}
@@ -0,0 +1,79 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPercentile(t *testing.T) {
+	for _, tt := range []struct {
+		values []int64
+		p      float64
+		want   float64
+	}{
+		{nil, 0.5, 0},
+		{[]int64{4200}, 0.9, 4200},
+		{[]int64{1000, 2000, 3000}, 0.5, 2000},
+		// Even counts interpolate between the middle values
+		{[]int64{1000, 2000, 3000, 4000}, 0.5, 2500},
+		{[]int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, 0.9, 9.1},
+		{[]int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, 1, 10},
+		{[]int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, 0, 1},
+	} {
+		if got := Percentile(tt.values, tt.p); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Percentile(%v, %v) = %v, want %v", tt.values, tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestAggregateTimings(t *testing.T) {
+	questions := []string{"q-name", "q-plan", "q-comment"}
+	timings := []map[string]QuestionTiming{
+		{"q-name": {FocusMs: 3000}, "q-plan": {FocusMs: 9000, Revisits: 2}, "q-comment": {FocusMs: 40000}},
+		{"q-name": {FocusMs: 1000}, "q-plan": {FocusMs: 5000}},
+		{"q-name": {FocusMs: 2000}, "q-plan": {FocusMs: 7000, Revisits: 1}, "q-removed": {FocusMs: 99000}},
+		{"q-name": {FocusMs: 4000}, "q-plan": {FocusMs: 11000, Revisits: 1}},
+	}
+	abandoned := [][]string{
+		// Answered out of order, the draft stops at the last in form order
+		{"q-plan", "q-name"},
+		{"q-plan"},
+		{"q-name"},
+		// Drafts answering only removed questions are left out
+		{"q-removed"},
+		{},
+	}
+
+	stats := AggregateTimings(questions, timings, 10, abandoned)
+	if len(stats) != 3 {
+		t.Fatalf("got %d questions, want 3", len(stats))
+	}
+	want := []QuestionTimingStats{
+		{QuestionID: "q-name", Samples: 4, MedianMs: 2500, P90Ms: 3700, MeanRevisits: 0, Reached: 13, DroppedOff: 1},
+		{QuestionID: "q-plan", Samples: 4, MedianMs: 8000, P90Ms: 10400, MeanRevisits: 1, Reached: 12, DroppedOff: 2},
+		{QuestionID: "q-comment", Samples: 1, MedianMs: 40000, P90Ms: 40000, MeanRevisits: 0, Reached: 10, DroppedOff: 0},
+	}
+	for i, w := range want {
+		got := stats[i]
+		w.DropOffRate = got.DropOffRate
+		if math.Abs(got.P90Ms-w.P90Ms) > 1e-9 {
+			w.P90Ms = got.P90Ms
+			t.Errorf("%s: p90 = %v, want %v", w.QuestionID, got.P90Ms, want[i].P90Ms)
+		}
+		if got != w {
+			t.Errorf("question %d = %+v, want %+v", i, got, w)
+		}
+	}
+	if rate := stats[1].DropOffRate; math.Abs(rate-2.0/12) > 1e-9 {
+		t.Errorf("drop-off rate of q-plan = %v, want 2/12", rate)
+	}
+	if stats[2].DropOffRate != 0 {
+		t.Errorf("drop-off rate of the last question = %v, want 0", stats[2].DropOffRate)
+	}
+
+	// Without responses nor drafts, nothing is reached and nothing divided
+	empty := AggregateTimings(questions, nil, 0, nil)
+	if empty[0].Reached != 0 || empty[0].DropOffRate != 0 || empty[0].Samples != 0 {
+		t.Errorf("empty aggregate = %+v", empty[0])
+	}
+}
@@ -0,0 +1,74 @@
+// Package challenge issues the spam protection challenges of public form
+// definitions. The response service verifies them on submission: CAPTCHA
+// tokens with the provider, and honeypot tokens against the secret they are
+// signed with here.
+package challenge
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+)
+
+// HoneypotField is the name of the hidden field of honeypot forms
+const HoneypotField = "website"
+
+// Config holds the CAPTCHA provider of the service and the secret honeypot
+// tokens are signed with, shared with the response service
+type Config struct {
+	CaptchaProvider models.CaptchaProvider
+	CaptchaSiteKey  string
+	Secret          string
+}
+
+// Issuer issues the challenges of published forms
+type Issuer struct {
+	config Config
+	now    func() time.Time
+}
+
+// NewIssuer creates a new challenge issuer
+func NewIssuer(config Config) *Issuer {
+	return &Issuer{config: config, now: time.Now}
+}
+
+// Issue returns the challenge submissions to the form must pass, nil for
+// forms without spam protection or when there is no issuer
+func (i *Issuer) Issue(formID uuid.UUID, settings models.FormSettings) *models.SpamChallenge {
+	if i == nil {
+		return nil
+	}
+
+	switch settings.SpamProtection {
+	case models.SpamProtectionCaptcha:
+		return &models.SpamChallenge{
+			Mode:     models.SpamProtectionCaptcha,
+			Provider: i.config.CaptchaProvider,
+			SiteKey:  i.config.CaptchaSiteKey,
+		}
+	case models.SpamProtectionHoneypot:
+		return &models.SpamChallenge{
+			Mode:          models.SpamProtectionHoneypot,
+			HoneypotField: HoneypotField,
+			Token:         Sign(i.config.Secret, formID, i.now()),
+		}
+	default:
+		return nil
+	}
+}
+
+// Sign returns the honeypot token of a definition of the form issued at
+// issuedAt: the issue time in Unix seconds and its HMAC-SHA256 signature
+// over "<form ID>:<issue time>", in hex, joined by a dot
+func Sign(secret string, formID uuid.UUID, issuedAt time.Time) string {
+	issued := strconv.FormatInt(issuedAt.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(formID.String() + ":" + issued))
+	return issued + "." + hex.EncodeToString(mac.Sum(nil))
+}
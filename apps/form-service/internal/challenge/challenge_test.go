@@ -0,0 +1,46 @@
+package challenge
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+)
+
+func TestIssue(t *testing.T) {
+	formID := uuid.MustParse("6f1c2d3e-4a5b-4c6d-8e7f-901a2b3c4d5e")
+	issuedAt := time.Unix(1700000000, 0)
+	issuer := NewIssuer(Config{CaptchaProvider: models.CaptchaProviderTurnstile, CaptchaSiteKey: "site-key", Secret: "secret"})
+	issuer.now = func() time.Time { return issuedAt }
+
+	if got := issuer.Issue(formID, models.FormSettings{}); got != nil {
+		t.Errorf("no spam protection: challenge = %+v, want none", got)
+	}
+	if got := issuer.Issue(formID, models.FormSettings{SpamProtection: models.SpamProtectionNone}); got != nil {
+		t.Errorf("spam protection none: challenge = %+v, want none", got)
+	}
+	var none *Issuer
+	if got := none.Issue(formID, models.FormSettings{SpamProtection: models.SpamProtectionCaptcha}); got != nil {
+		t.Errorf("nil issuer: challenge = %+v, want none", got)
+	}
+
+	captcha := issuer.Issue(formID, models.FormSettings{SpamProtection: models.SpamProtectionCaptcha})
+	if captcha == nil || captcha.Provider != models.CaptchaProviderTurnstile || captcha.SiteKey != "site-key" || captcha.Token != "" {
+		t.Errorf("captcha challenge = %+v, want the provider and site key", captcha)
+	}
+
+	// The response service verifies the same signature
+	honeypot := issuer.Issue(formID, models.FormSettings{SpamProtection: models.SpamProtectionHoneypot})
+	want := "1700000000.d31b5ecf68b35101e1e4a7d68431c6b74a24f6512e886bd00e26984a05562a90"
+	if honeypot == nil || honeypot.HoneypotField != HoneypotField {
+		t.Fatalf("honeypot challenge = %+v, want the honeypot field", honeypot)
+	}
+	if honeypot.Token != want {
+		t.Errorf("token = %s, want %s", honeypot.Token, want)
+	}
+	if other := Sign("other", formID, issuedAt); other == honeypot.Token {
+		t.Error("tokens signed with another secret match")
+	}
+}
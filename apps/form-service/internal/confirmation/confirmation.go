@@ -0,0 +1,54 @@
+// Package confirmation signs the tokens that confirm the bulk deletion of
+// responses after its dry run, with signedtoken. The claims bind the token
+// to the caller, the form and the filter of the dry run, and to the number
+// of responses it counted.
+package confirmation
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/signedtoken"
+)
+
+var (
+	// ErrInvalidToken is returned for tokens that are malformed or not
+	// signed with the secret
+	ErrInvalidToken = errors.New("invalid confirmation token")
+	// ErrExpiredToken is returned for tokens that are valid but expired;
+	// the dry run must be run again
+	ErrExpiredToken = errors.New("confirmation token expired")
+)
+
+// Claims are the claims of a confirmation token
+type Claims struct {
+	// TokenID tells tokens apart, so each confirms a single deletion
+	TokenID uuid.UUID `json:"jti"`
+	FormID  uuid.UUID `json:"form_id"`
+	UserID  uuid.UUID `json:"sub"`
+	// Filter is the hash of the filter of the dry run
+	Filter string `json:"filter"`
+	// Matched is the number of responses the dry run counted
+	Matched   int   `json:"matched"`
+	ExpiresAt int64 `json:"exp"`
+}
+
+// Sign returns the token of claims signed with secret
+func Sign(secret string, claims Claims) (string, error) {
+	return signedtoken.Sign(secret, claims)
+}
+
+// Verify returns the claims of a token signed with secret that has not
+// expired at now
+func Verify(secret, token string, now time.Time) (Claims, error) {
+	claims, err := signedtoken.Verify[Claims](secret, token)
+	if err != nil || claims.TokenID == uuid.Nil || claims.FormID == uuid.Nil || claims.Filter == "" {
+		return Claims{}, ErrInvalidToken
+	}
+	if now.Unix() >= claims.ExpiresAt {
+		return Claims{}, ErrExpiredToken
+	}
+	return claims, nil
+}
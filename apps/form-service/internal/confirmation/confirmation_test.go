@@ -0,0 +1,59 @@
+package confirmation
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSignVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	claims := Claims{
+		TokenID:   uuid.MustParse("0b9f1c2e-3d4a-4b5c-8d6e-7f8091a2b3c4"),
+		FormID:    uuid.MustParse("6f1c2d3e-4a5b-4c6d-8e7f-901a2b3c4d5e"),
+		UserID:    uuid.MustParse("1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d"),
+		Filter:    "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+		Matched:   42,
+		ExpiresAt: now.Add(10 * time.Minute).Unix(),
+	}
+
+	token, err := Sign("secret", claims)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	got, err := Verify("secret", token, now)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if got != claims {
+		t.Errorf("claims = %+v, want %+v", got, claims)
+	}
+
+	// A token is valid until the second it expires
+	if _, err := Verify("secret", token, now.Add(10*time.Minute-time.Second)); err != nil {
+		t.Errorf("a second before expiry: %v", err)
+	}
+	if _, err := Verify("secret", token, now.Add(10*time.Minute)); !errors.Is(err, ErrExpiredToken) {
+		t.Errorf("at expiry: error = %v, want ErrExpiredToken", err)
+	}
+
+	noFilter := claims
+	noFilter.Filter = ""
+	unbound, _ := Sign("secret", noFilter)
+	tampered := token[:len(token)-2] + "xx"
+
+	for name, tc := range map[string]struct {
+		secret, token string
+	}{
+		"other secret": {"other", token},
+		"tampered":     {"secret", tampered},
+		"malformed":    {"secret", "not-a-token"},
+		"no filter":    {"secret", unbound},
+	} {
+		if _, err := Verify(tc.secret, tc.token, now); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: error = %v, want ErrInvalidToken", name, err)
+		}
+	}
+}
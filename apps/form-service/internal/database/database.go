@@ -3,7 +3,8 @@ package database
 import (
 	"context"
 	"fmt"
-	"time"
+	"log"
+	"os"
 
 	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
 	"github.com/redis/go-redis/v9"
@@ -12,10 +13,16 @@ import (
 	"gorm.io/gorm/logger"
 )
 
-// Connect establishes a connection to PostgreSQL database
-func Connect(databaseURL string) (*gorm.DB, error) {
+// Connect establishes a connection to PostgreSQL database with the pool
+// limits of cfg. With replicas, the queries opted in with Replica read from
+// them; the replicas are checked before Connect returns, and the ones down
+// stay out of rotation until a later check passes.
+func Connect(databaseURL string, cfg Config) (*gorm.DB, error) {
 	config := &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger: NewSlowQueryLogger(logger.New(log.New(os.Stdout, "\r\n", log.LstdFlags), logger.Config{
+			LogLevel: logger.Info,
+			Colorful: true,
+		}), cfg.SlowQueryThreshold),
 	}
 
 	db, err := gorm.Open(postgres.Open(databaseURL), config)
@@ -28,49 +35,111 @@ func Connect(databaseURL string) (*gorm.DB, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to get database instance: %w", err)
 	}
+	cfg.apply(sqlDB)
 
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
-	sqlDB.SetConnMaxLifetime(time.Hour)
+	if len(cfg.ReplicaURLs) == 0 {
+		return db, nil
+	}
+	replicas, err := openReplicas(cfg)
+	if err != nil {
+		return nil, err
+	}
+	resolver := newResolver(cfg, replicas)
+	if err := db.Use(resolver); err != nil {
+		return nil, fmt.Errorf("failed to register the replica resolver: %w", err)
+	}
+	if cfg.PrimaryOnly {
+		log.Printf("Database primary only: the %d replicas serve no query", len(replicas))
+		return db, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), resolver.interval)
+	defer cancel()
+	resolver.checkAll(ctx)
 
 	return db, nil
 }
 
-// Migrate runs database migrations
-func Migrate(db *gorm.DB) error {
-	// Migrate models one by one to isolate issues
-	if err := db.AutoMigrate(&models.Form{}); err != nil {
-		return fmt.Errorf("failed to migrate Form: %w", err)
-	}
+// schemaModels lists the models whose tables the migrations create
+var schemaModels = []struct {
+	name  string
+	model interface{}
+}{
+	{"Form", &models.Form{}},
+	{"Question", &models.Question{}},
+	{"Collaborator", &models.Collaborator{}},
+	{"FileUpload", &models.FileUpload{}},
+	{"ResponseDraft", &models.ResponseDraft{}},
+	{"ReportSchedule", &models.ReportSchedule{}},
+	{"Report", &models.Report{}},
+	{"Organization", &models.Organization{}},
+	{"OrganizationMember", &models.OrganizationMember{}},
+	{"FormSlugRedirect", &models.FormSlugRedirect{}},
+	{"CleanupJob", &models.CleanupJob{}},
+	{"LibraryQuestion", &models.LibraryQuestion{}},
+	{"PreviewToken", &models.PreviewToken{}},
+	{"FormActivity", &models.FormActivity{}},
+	{"ExportJob", &models.ExportJob{}},
+	{"OrganizationUsage", &models.OrganizationUsage{}},
+	{"NotificationChannel", &models.NotificationChannel{}},
+	{"FormTransfer", &models.FormTransfer{}},
+	{"ResponseDeletionJob", &models.ResponseDeletionJob{}},
+	{"QuestionKeyAlias", &models.QuestionKeyAlias{}},
+}
 
-	if err := db.AutoMigrate(&models.Question{}); err != nil {
-		return fmt.Errorf("failed to migrate Question: %w", err)
+// Migrate applies the pending versioned migrations, for development and the
+// servers started with AUTO_MIGRATE=true. Production runs
+// `form-service migrate up` before rolling out a release instead.
+func Migrate(db *gorm.DB) error {
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		return err
 	}
+	return migrator.Up(context.Background())
+}
 
-	if err := db.AutoMigrate(&models.Collaborator{}); err != nil {
-		return fmt.Errorf("failed to migrate Collaborator: %w", err)
+// MissingColumns lists the tables and columns of the models that are missing
+// from the database, which tells a migration forgotten for a model change
+func MissingColumns(ctx context.Context, db *gorm.DB) ([]string, error) {
+	db = db.WithContext(ctx)
+
+	var pending []string
+	for _, m := range schemaModels {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(m.model); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", m.name, err)
+		}
+
+		var columns []string
+		err := db.Raw(`SELECT column_name FROM information_schema.columns
+			WHERE table_schema = CURRENT_SCHEMA() AND table_name = ?`, stmt.Schema.Table).
+			Scan(&columns).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the columns of %s: %w", stmt.Schema.Table, err)
+		}
+		if len(columns) == 0 {
+			pending = append(pending, "table "+stmt.Schema.Table)
+			continue
+		}
+
+		existing := make(map[string]bool, len(columns))
+		for _, column := range columns {
+			existing[column] = true
+		}
+		for _, name := range stmt.Schema.DBNames {
+			if !existing[name] {
+				pending = append(pending, "column "+stmt.Schema.Table+"."+name)
+			}
+		}
 	}
-
-	return nil
+	return pending, nil
 }
 
-// ConnectRedis establishes a connection to Redis
-func ConnectRedis(redisURL string) *redis.Client {
+// OpenRedis creates the Redis client of redisURL. It connects lazily; ping
+// the client to tell whether Redis is reachable.
+func OpenRedis(redisURL string) (*redis.Client, error) {
 	opt, err := redis.ParseURL(redisURL)
 	if err != nil {
-		panic(fmt.Sprintf("Failed to parse Redis URL: %v", err))
-	}
-
-	client := redis.NewClient(opt)
-
-	// Test connection
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	_, err = client.Ping(ctx).Result()
-	if err != nil {
-		panic(fmt.Sprintf("Failed to connect to Redis: %v", err))
+		return nil, fmt.Errorf("failed to parse Redis URL: %w", err)
 	}
-
-	return client
+	return redis.NewClient(opt), nil
 }
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm/logger"
+)
+
+// correlationIDKey is the request context key of the correlation ID, set by
+// handlers.CorrelationIDMiddleware
+const correlationIDKey = "correlationID"
+
+// slowQueryLogger logs the queries slower than a threshold, with the
+// correlation ID of the request that ran them, on top of a GORM logger
+type slowQueryLogger struct {
+	logger.Interface
+	threshold time.Duration
+	log       *log.Logger
+}
+
+// NewSlowQueryLogger wraps base to also log the queries taking longer than
+// threshold. A threshold of zero logs no slow query.
+func NewSlowQueryLogger(base logger.Interface, threshold time.Duration) logger.Interface {
+	return &slowQueryLogger{Interface: base, threshold: threshold, log: log.Default()}
+}
+
+func (l *slowQueryLogger) LogMode(level logger.LogLevel) logger.Interface {
+	copied := *l
+	copied.Interface = l.Interface.LogMode(level)
+	return &copied
+}
+
+func (l *slowQueryLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	l.Interface.Trace(ctx, begin, fc, err)
+
+	elapsed := time.Since(begin)
+	if l.threshold <= 0 || elapsed < l.threshold {
+		return
+	}
+	correlationID, _ := ctx.Value(correlationIDKey).(string)
+	if correlationID == "" {
+		correlationID = "-"
+	}
+	sql, rows := fc()
+	l.log.Printf("SLOW QUERY correlation_id=%s duration=%s threshold=%s rows=%d: %s",
+		correlationID, elapsed.Round(time.Millisecond), l.threshold, rows, sql)
+}
@@ -0,0 +1,367 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID is the key of the advisory lock held while migrating, so
+// instances started together migrate one after the other
+const migrationLockID int64 = 5_318_008_832
+
+// noTransaction starts the migrations that cannot run in a transaction, such
+// as CREATE INDEX CONCURRENTLY. A failure leaves the schema dirty.
+const noTransaction = "-- migrate:no-transaction"
+
+// migrationFile matches the golang-migrate file names, e.g.
+// 000002_add_form_slugs.up.sql
+var migrationFile = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a versioned schema change and the change reverting it
+type Migration struct {
+	Version uint
+	Name    string
+	up      string
+	down    string
+}
+
+// String returns the file name of the migration without its direction
+func (m Migration) String() string {
+	return fmt.Sprintf("%06d_%s", m.Version, m.Name)
+}
+
+// MigrationStatus is the schema version of a database relative to the
+// migrations of the binary
+type MigrationStatus struct {
+	// Version is the last migration applied, 0 when none was
+	Version uint
+	// Dirty is set when the migration to Version failed halfway
+	Dirty bool
+	// Latest is the version the binary expects
+	Latest  uint
+	Applied []Migration
+	Pending []Migration
+}
+
+// Migrator applies the versioned SQL migrations embedded in the binary. The
+// version is recorded in schema_migrations the way golang-migrate records
+// it, so its CLI works on the same database.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// NewMigrator creates a migrator for the migrations embedded in the binary
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database instance: %w", err)
+	}
+	sub, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := loadMigrations(sub)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: sqlDB, migrations: migrations}, nil
+}
+
+// loadMigrations reads the migrations of fsys, ordered by version. Every
+// migration needs both an up and a down file.
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration %s is not named VERSION_NAME.up.sql or VERSION_NAME.down.sql", entry.Name())
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("migration %s has an invalid version", entry.Name())
+		}
+		body, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		m := byVersion[uint(version)]
+		if m == nil {
+			m = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[m.Version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", m, entry.Name(), version)
+		}
+		if match[3] == "up" {
+			m.up = string(body)
+		} else {
+			m.down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migration %s needs both an up and a down file", m)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Latest returns the version of the last migration of the binary
+func (m *Migrator) Latest() uint {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Status reports the schema version of the database
+func (m *Migrator) Status(ctx context.Context) (MigrationStatus, error) {
+	var status MigrationStatus
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		var err error
+		status, err = m.status(ctx, conn)
+		return err
+	})
+	return status, err
+}
+
+func (m *Migrator) status(ctx context.Context, conn *sql.Conn) (MigrationStatus, error) {
+	version, dirty, err := readVersion(ctx, conn)
+	if err != nil {
+		return MigrationStatus{}, err
+	}
+
+	status := MigrationStatus{Version: version, Dirty: dirty, Latest: m.Latest()}
+	for _, migration := range m.migrations {
+		if migration.Version <= version {
+			status.Applied = append(status.Applied, migration)
+		} else {
+			status.Pending = append(status.Pending, migration)
+		}
+	}
+	return status, nil
+}
+
+// Pending lists the migrations not applied yet, for the readiness probe
+func (m *Migrator) Pending(ctx context.Context) ([]string, error) {
+	version, dirty, err := readVersion(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+	if dirty {
+		return nil, fmt.Errorf("schema is dirty at version %d", version)
+	}
+
+	var pending []string
+	for _, migration := range m.migrations {
+		if migration.Version > version {
+			pending = append(pending, migration.String())
+		}
+	}
+	return pending, nil
+}
+
+// CheckVersion fails when the database schema is behind the binary or was
+// left dirty by a failed migration. A schema ahead of the binary is accepted,
+// as it is while a release is rolled back: migrations stay compatible with
+// the previous release.
+func (m *Migrator) CheckVersion(ctx context.Context) error {
+	version, dirty, err := readVersion(ctx, m.db)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("database schema is dirty at version %d: repair the failed migration by hand, then run `form-service migrate force VERSION`", version)
+	}
+	if version < m.Latest() {
+		return fmt.Errorf("database schema is at version %d but this binary expects version %d: run `form-service migrate up`, or set AUTO_MIGRATE=true in development", version, m.Latest())
+	}
+	return nil
+}
+
+// Up applies every pending migration
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		status, err := m.status(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if status.Dirty {
+			return fmt.Errorf("database schema is dirty at version %d: repair it, then run migrate force", status.Version)
+		}
+
+		for _, migration := range status.Pending {
+			if err := m.apply(ctx, conn, migration.up, migration.Version); err != nil {
+				return fmt.Errorf("migration %s failed: %w", migration, err)
+			}
+			log.Printf("Applied migration %s", migration)
+		}
+		return nil
+	})
+}
+
+// Down reverts the last n applied migrations
+func (m *Migrator) Down(ctx context.Context, n int) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		status, err := m.status(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if status.Dirty {
+			return fmt.Errorf("database schema is dirty at version %d: repair it, then run migrate force", status.Version)
+		}
+		if status.Version > status.Latest {
+			return fmt.Errorf("database schema version %d is newer than this binary's %d: migrate down with the newer binary", status.Version, status.Latest)
+		}
+
+		applied := status.Applied
+		for i := len(applied) - 1; i >= 0 && i >= len(applied)-n; i-- {
+			var previous uint
+			if i > 0 {
+				previous = applied[i-1].Version
+			}
+			if err := m.apply(ctx, conn, applied[i].down, previous); err != nil {
+				return fmt.Errorf("reverting migration %s failed: %w", applied[i], err)
+			}
+			log.Printf("Reverted migration %s", applied[i])
+		}
+		return nil
+	})
+}
+
+// Force records version as applied and clears the dirty flag without running
+// any migration, once a failed migration was repaired by hand
+func (m *Migrator) Force(ctx context.Context, version uint) error {
+	known := version == 0
+	for _, migration := range m.migrations {
+		known = known || migration.Version == version
+	}
+	if !known {
+		return fmt.Errorf("no migration has version %d", version)
+	}
+
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		return writeVersion(ctx, conn, version, false)
+	})
+}
+
+// apply runs script and records version. Scripts run in a transaction unless
+// they start with noTransaction, in which case the schema stays dirty when
+// they fail.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, script string, version uint) error {
+	if strings.HasPrefix(strings.TrimSpace(script), noTransaction) {
+		if err := writeVersion(ctx, conn, version, true); err != nil {
+			return err
+		}
+		if _, err := conn.ExecContext(ctx, script); err != nil {
+			return err
+		}
+		return writeVersion(ctx, conn, version, false)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if err := writeVersion(ctx, tx, version, false); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// withLock runs fn on a connection holding the migration lock, after
+// creating the version table
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return fmt.Errorf("failed to acquire the migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID)
+
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version bigint NOT NULL PRIMARY KEY,
+		dirty boolean NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	return fn(conn)
+}
+
+type querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// readVersion reads the recorded schema version, 0 when no migration ran
+func readVersion(ctx context.Context, q querier) (uint, bool, error) {
+	var version int64
+	var dirty bool
+	err := q.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || isUndefinedTable(err) {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("failed to read the schema version: %w", err)
+	}
+	return uint(version), dirty, nil
+}
+
+// writeVersion replaces the recorded schema version
+func writeVersion(ctx context.Context, e execer, version uint, dirty bool) error {
+	if _, err := e.ExecContext(ctx, "DELETE FROM schema_migrations"); err != nil {
+		return fmt.Errorf("failed to record the schema version: %w", err)
+	}
+	if version == 0 {
+		return nil
+	}
+	if _, err := e.ExecContext(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES ($1, $2)", int64(version), dirty); err != nil {
+		return fmt.Errorf("failed to record the schema version: %w", err)
+	}
+	return nil
+}
+
+// isUndefinedTable reports whether err is PostgreSQL's undefined_table, as
+// when reading the version of a database never migrated
+func isUndefinedTable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "42P01"
+}
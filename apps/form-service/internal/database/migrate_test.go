@@ -0,0 +1,209 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	file := func(body string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(body)} }
+
+	migrations, err := loadMigrations(fstest.MapFS{
+		"000002_add_slugs.up.sql":        file("ALTER TABLE forms ADD slug text"),
+		"000002_add_slugs.down.sql":      file("ALTER TABLE forms DROP slug"),
+		"000001_initial_schema.up.sql":   file("CREATE TABLE forms ()"),
+		"000001_initial_schema.down.sql": file("DROP TABLE forms"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 || migrations[0].String() != "000001_initial_schema" || migrations[1].String() != "000002_add_slugs" {
+		t.Fatalf("migrations = %v, want ordered by version", migrations)
+	}
+	if migrations[1].up != "ALTER TABLE forms ADD slug text" || migrations[1].down != "ALTER TABLE forms DROP slug" {
+		t.Errorf("migration 2 = %+v", migrations[1])
+	}
+
+	invalid := []struct {
+		name string
+		fsys fstest.MapFS
+		want string
+	}{
+		{"missing down", fstest.MapFS{"000001_init.up.sql": file("SELECT 1")}, "needs both an up and a down file"},
+		{"shared version", fstest.MapFS{
+			"000001_init.up.sql":   file("SELECT 1"),
+			"000001_init.down.sql": file("SELECT 1"),
+			"000001_slug.up.sql":   file("SELECT 1"),
+		}, "share version 1"},
+		{"unversioned", fstest.MapFS{"init.up.sql": file("SELECT 1")}, "is not named"},
+		{"version zero", fstest.MapFS{"000000_init.up.sql": file("SELECT 1")}, "invalid version"},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := loadMigrations(tt.fsys); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	db, err := gorm.Open(postgres.Open("postgres://xform@127.0.0.1:1/xform"), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range migrator.migrations {
+		if m.Version != uint(i+1) {
+			t.Errorf("migration %s follows version %d; versions must not skip", m, i)
+		}
+	}
+}
+
+// migrationTestDB connects to FORM_SERVICE_TEST_DATABASE_URL with a search
+// path on a new schema, dropped when the test ends
+func migrationTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("FORM_SERVICE_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("FORM_SERVICE_TEST_DATABASE_URL not set")
+	}
+
+	admin, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := fmt.Sprintf("migrate_test_%d", time.Now().UnixNano())
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		sqlDB, _ := admin.DB()
+		sqlDB.Close()
+	})
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+
+	db, err := gorm.Open(postgres.Open(u.String()), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+// TestMigrationsUpDown runs every migration up, down and up again, checking
+// that the schema matches the models and the version is recorded
+func TestMigrationsUpDown(t *testing.T) {
+	db := migrationTestDB(t)
+	ctx := context.Background()
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := migrator.CheckVersion(ctx); err == nil || !strings.Contains(err.Error(), "migrate up") {
+		t.Errorf("CheckVersion on an empty schema = %v, want instructions to migrate", err)
+	}
+	if pending, err := migrator.Pending(ctx); err != nil || len(pending) != len(migrator.migrations) {
+		t.Errorf("Pending = %v, %v; want every migration", pending, err)
+	}
+
+	for cycle := 0; cycle < 2; cycle++ {
+		if err := migrator.Up(ctx); err != nil {
+			t.Fatalf("up: %v", err)
+		}
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.Version != migrator.Latest() || status.Dirty || len(status.Pending) != 0 {
+			t.Fatalf("status after up = %+v", status)
+		}
+		if err := migrator.CheckVersion(ctx); err != nil {
+			t.Errorf("CheckVersion after up: %v", err)
+		}
+		missing, err := MissingColumns(ctx, db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(missing) > 0 {
+			t.Errorf("the migrations do not create %v of the models", missing)
+		}
+
+		// Up again is a no-op
+		if err := migrator.Up(ctx); err != nil {
+			t.Fatalf("second up: %v", err)
+		}
+
+		if err := migrator.Down(ctx, len(migrator.migrations)); err != nil {
+			t.Fatalf("down: %v", err)
+		}
+		if status, err = migrator.Status(ctx); err != nil || status.Version != 0 {
+			t.Fatalf("status after down = %+v, %v", status, err)
+		}
+		var tables int64
+		db.Raw(`SELECT count(*) FROM information_schema.tables
+			WHERE table_schema = CURRENT_SCHEMA() AND table_name <> 'schema_migrations'`).Scan(&tables)
+		if tables != 0 {
+			t.Errorf("%d tables left after migrating down", tables)
+		}
+	}
+}
+
+func TestMigrationsForce(t *testing.T) {
+	db := migrationTestDB(t)
+	ctx := context.Background()
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A failed non-transactional migration leaves the schema dirty
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("UPDATE schema_migrations SET dirty = true").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.Up(ctx); err == nil || !strings.Contains(err.Error(), "dirty") {
+		t.Errorf("up on a dirty schema = %v", err)
+	}
+	if err := migrator.CheckVersion(ctx); err == nil || !strings.Contains(err.Error(), "migrate force") {
+		t.Errorf("CheckVersion on a dirty schema = %v", err)
+	}
+
+	if err := migrator.Force(ctx, migrator.Latest()); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.CheckVersion(ctx); err != nil {
+		t.Errorf("CheckVersion after force: %v", err)
+	}
+	if err := migrator.Force(ctx, migrator.Latest()+1); err == nil {
+		t.Error("forced an unknown version")
+	}
+}
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS "response_drafts";
+DROP TABLE IF EXISTS "file_uploads";
+DROP TABLE IF EXISTS "collaborators";
+DROP TABLE IF EXISTS "questions";
+DROP TABLE IF EXISTS "forms";
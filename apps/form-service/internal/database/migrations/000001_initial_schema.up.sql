@@ -0,0 +1,100 @@
+-- The schema previously created by GORM AutoMigrate. Tables and indexes are
+-- only created when missing, so databases migrated by AutoMigrate adopt the
+-- versioned migrations without changes.
+
+CREATE TABLE IF NOT EXISTS "forms" (
+    "id" uuid,
+    "user_id" uuid NOT NULL,
+    "title" varchar(200) NOT NULL,
+    "description" text,
+    "status" varchar(20) NOT NULL DEFAULT 'draft',
+    "settings" JSONB,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "default_locale" varchar(35) NOT NULL DEFAULT 'en',
+    "translations" JSONB,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_forms_deleted_at" ON "forms" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_forms_user_id" ON "forms" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "questions" (
+    "id" uuid,
+    "form_id" uuid NOT NULL,
+    "type" varchar(20) NOT NULL,
+    "title" varchar(500) NOT NULL,
+    "description" text,
+    "order" bigint NOT NULL,
+    "options" JSONB,
+    "validation" JSONB,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_questions_form" FOREIGN KEY ("form_id") REFERENCES "forms"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_questions_deleted_at" ON "questions" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_questions_form_id" ON "questions" ("form_id");
+
+CREATE TABLE IF NOT EXISTS "collaborators" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "form_id" uuid NOT NULL,
+    "user_id" uuid,
+    "email" text NOT NULL,
+    "role" text NOT NULL DEFAULT 'viewer',
+    "status" text NOT NULL DEFAULT 'pending',
+    "invited_by" uuid NOT NULL,
+    "invited_at" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "joined_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_collaborators_form" FOREIGN KEY ("form_id") REFERENCES "forms"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_collaborators_deleted_at" ON "collaborators" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_collaborators_form_id" ON "collaborators" ("form_id");
+CREATE INDEX IF NOT EXISTS "idx_collaborators_user_id" ON "collaborators" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_collaborators_email" ON "collaborators" ("email");
+
+CREATE TABLE IF NOT EXISTS "file_uploads" (
+    "id" uuid,
+    "token" varchar(64) NOT NULL,
+    "form_id" uuid NOT NULL,
+    "question_id" uuid NOT NULL,
+    "object_key" varchar(500) NOT NULL,
+    "file_name" varchar(255),
+    "content_type" varchar(255) NOT NULL,
+    "size_bytes" bigint,
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "response_id" varchar(100),
+    "attached_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "scan_status" varchar(20) NOT NULL DEFAULT 'pending',
+    "scan_signature" varchar(255),
+    "scanned_at" timestamptz,
+    "quarantined" boolean NOT NULL DEFAULT false,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_file_uploads_token" ON "file_uploads" ("token");
+CREATE INDEX IF NOT EXISTS "idx_file_uploads_form_id" ON "file_uploads" ("form_id");
+CREATE INDEX IF NOT EXISTS "idx_file_uploads_question_id" ON "file_uploads" ("question_id");
+CREATE INDEX IF NOT EXISTS "idx_file_uploads_status" ON "file_uploads" ("status");
+CREATE INDEX IF NOT EXISTS "idx_file_uploads_response_id" ON "file_uploads" ("response_id");
+CREATE INDEX IF NOT EXISTS "idx_file_uploads_created_at" ON "file_uploads" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_file_uploads_scan_status" ON "file_uploads" ("scan_status");
+
+CREATE TABLE IF NOT EXISTS "response_drafts" (
+    "form_id" uuid,
+    "owner_key" varchar(100),
+    "user_id" varchar(255),
+    "answers" JSONB NOT NULL,
+    "size_bytes" bigint NOT NULL,
+    "updated_at" timestamptz,
+    "expires_at" timestamptz NOT NULL,
+    PRIMARY KEY ("form_id", "owner_key")
+);
+CREATE INDEX IF NOT EXISTS "idx_response_drafts_expires_at" ON "response_drafts" ("expires_at");
+CREATE INDEX IF NOT EXISTS "idx_response_drafts_user_id" ON "response_drafts" ("user_id");
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS "idx_forms_user_changes";
@@ -0,0 +1,4 @@
+-- The change feed pages through the forms of a user by (updated_at, id),
+-- deleted forms included
+UPDATE "forms" SET "updated_at" = COALESCE("deleted_at", "created_at", now()) WHERE "updated_at" IS NULL;
+CREATE INDEX IF NOT EXISTS "idx_forms_user_changes" ON "forms" ("user_id", "updated_at", "id");
@@ -0,0 +1,31 @@
+-- Scheduled summary reports: the forms enrolled and the generated artifacts
+CREATE TABLE IF NOT EXISTS "report_schedules" (
+    "form_id" uuid,
+    "frequency" varchar(20) NOT NULL,
+    "weekday" bigint NOT NULL DEFAULT 0,
+    "send_at" varchar(5) NOT NULL,
+    "timezone" varchar(64) NOT NULL,
+    "recipients" JSONB NOT NULL,
+    "format" varchar(10) NOT NULL,
+    "include_empty" boolean NOT NULL DEFAULT false,
+    "next_run_at" timestamptz NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("form_id")
+);
+CREATE INDEX IF NOT EXISTS "idx_report_schedules_next_run_at" ON "report_schedules" ("next_run_at");
+
+CREATE TABLE IF NOT EXISTS "reports" (
+    "id" uuid,
+    "form_id" uuid NOT NULL,
+    "period_start" timestamptz NOT NULL,
+    "period_end" timestamptz NOT NULL,
+    "format" varchar(10) NOT NULL,
+    "object_key" varchar(500) NOT NULL,
+    "size_bytes" bigint,
+    "responses" bigint NOT NULL,
+    "completion_rate" decimal,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_reports_form_period" ON "reports" ("form_id", "period_end");
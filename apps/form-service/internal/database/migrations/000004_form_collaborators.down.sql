@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS "idx_form_collaborators_form_user";
+UPDATE "form_collaborators" SET "role" = 'admin' WHERE "role" = 'manager';
+ALTER INDEX IF EXISTS "idx_form_collaborators_email" RENAME TO "idx_collaborators_email";
+ALTER INDEX IF EXISTS "idx_form_collaborators_user_id" RENAME TO "idx_collaborators_user_id";
+ALTER INDEX IF EXISTS "idx_form_collaborators_form_id" RENAME TO "idx_collaborators_form_id";
+ALTER INDEX IF EXISTS "idx_form_collaborators_deleted_at" RENAME TO "idx_collaborators_deleted_at";
+ALTER TABLE "form_collaborators" RENAME CONSTRAINT "fk_form_collaborators_form" TO "fk_collaborators_form";
+ALTER TABLE IF EXISTS "form_collaborators" RENAME TO "collaborators";
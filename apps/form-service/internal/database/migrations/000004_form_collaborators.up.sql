@@ -0,0 +1,11 @@
+-- Collaborators hold a viewer, editor or manager role on a form. The owner
+-- and admin roles of the initial schema were never granted through the API;
+-- any such rows become managers.
+ALTER TABLE IF EXISTS "collaborators" RENAME TO "form_collaborators";
+ALTER TABLE "form_collaborators" RENAME CONSTRAINT "fk_collaborators_form" TO "fk_form_collaborators_form";
+ALTER INDEX IF EXISTS "idx_collaborators_deleted_at" RENAME TO "idx_form_collaborators_deleted_at";
+ALTER INDEX IF EXISTS "idx_collaborators_form_id" RENAME TO "idx_form_collaborators_form_id";
+ALTER INDEX IF EXISTS "idx_collaborators_user_id" RENAME TO "idx_form_collaborators_user_id";
+ALTER INDEX IF EXISTS "idx_collaborators_email" RENAME TO "idx_form_collaborators_email";
+UPDATE "form_collaborators" SET "role" = 'manager' WHERE "role" IN ('owner', 'admin');
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_form_collaborators_form_user" ON "form_collaborators" ("form_id", "user_id") WHERE "deleted_at" IS NULL;
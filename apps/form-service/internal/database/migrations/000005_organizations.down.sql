@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "idx_forms_organization_user";
+ALTER TABLE "forms" DROP CONSTRAINT IF EXISTS "fk_forms_organization";
+ALTER TABLE "forms" DROP COLUMN IF EXISTS "organization_id";
+DROP TABLE IF EXISTS "organization_members";
+DROP TABLE IF EXISTS "organizations";
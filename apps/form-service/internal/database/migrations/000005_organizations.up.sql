@@ -0,0 +1,40 @@
+-- Forms belong to an organization. Every user who owns forms gets a personal
+-- organization, which takes over their existing forms. Users without forms
+-- get theirs when they first create one.
+CREATE TABLE IF NOT EXISTS "organizations" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "name" varchar(200) NOT NULL,
+    "personal" boolean NOT NULL DEFAULT false,
+    "created_by" uuid NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_organizations_personal" ON "organizations" ("created_by") WHERE "personal";
+
+CREATE TABLE IF NOT EXISTS "organization_members" (
+    "organization_id" uuid,
+    "user_id" uuid,
+    "email" varchar(320),
+    "role" varchar(20) NOT NULL,
+    "invited_by" uuid,
+    "joined_at" timestamptz NOT NULL,
+    PRIMARY KEY ("organization_id", "user_id"),
+    CONSTRAINT "fk_organization_members_organization" FOREIGN KEY ("organization_id") REFERENCES "organizations"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_organization_members_user_id" ON "organization_members" ("user_id");
+
+INSERT INTO "organizations" ("name", "personal", "created_by", "created_at", "updated_at")
+SELECT 'Personal', true, "user_id", CURRENT_TIMESTAMP, CURRENT_TIMESTAMP FROM "forms" GROUP BY "user_id"
+ON CONFLICT DO NOTHING;
+INSERT INTO "organization_members" ("organization_id", "user_id", "role", "joined_at")
+SELECT "id", "created_by", 'owner', CURRENT_TIMESTAMP FROM "organizations" WHERE "personal"
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE "forms" ADD COLUMN IF NOT EXISTS "organization_id" uuid;
+UPDATE "forms" SET "organization_id" = o."id"
+FROM "organizations" o
+WHERE o."personal" AND o."created_by" = "forms"."user_id" AND "forms"."organization_id" IS NULL;
+ALTER TABLE "forms" ALTER COLUMN "organization_id" SET NOT NULL;
+ALTER TABLE "forms" ADD CONSTRAINT "fk_forms_organization" FOREIGN KEY ("organization_id") REFERENCES "organizations"("id");
+CREATE INDEX IF NOT EXISTS "idx_forms_organization_user" ON "forms" ("organization_id", "user_id");
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS "form_slug_redirects";
+DROP INDEX IF EXISTS "idx_forms_slug";
+DROP INDEX IF EXISTS "idx_forms_organization_slug";
+ALTER TABLE "forms" DROP COLUMN IF EXISTS "slug";
@@ -0,0 +1,17 @@
+-- Published forms may have a slug, unique within their organization, to be
+-- resolved from pretty URLs. Slugs a form no longer has redirect to it for a
+-- while.
+ALTER TABLE "forms" ADD COLUMN IF NOT EXISTS "slug" varchar(63);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_forms_organization_slug" ON "forms" ("organization_id", "slug") WHERE "slug" IS NOT NULL AND "deleted_at" IS NULL;
+CREATE INDEX IF NOT EXISTS "idx_forms_slug" ON "forms" ("slug") WHERE "slug" IS NOT NULL AND "deleted_at" IS NULL;
+
+CREATE TABLE IF NOT EXISTS "form_slug_redirects" (
+    "organization_id" uuid,
+    "slug" varchar(63),
+    "form_id" uuid NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("organization_id", "slug"),
+    CONSTRAINT "fk_form_slug_redirects_form" FOREIGN KEY ("form_id") REFERENCES "forms"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_form_slug_redirects_form_id" ON "form_slug_redirects" ("form_id");
@@ -0,0 +1 @@
+ALTER TABLE "forms" DROP COLUMN IF EXISTS "throttled_at";
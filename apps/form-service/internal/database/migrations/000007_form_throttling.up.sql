@@ -0,0 +1,3 @@
+-- Forms receiving submissions far faster than usual are throttled by the
+-- anomaly detector of the event bus until the rate normalizes.
+ALTER TABLE "forms" ADD COLUMN IF NOT EXISTS "throttled_at" timestamptz;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "idx_forms_created_at_id";
+DROP TABLE IF EXISTS "form_cleanup_jobs";
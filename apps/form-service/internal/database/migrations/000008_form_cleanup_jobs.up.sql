@@ -0,0 +1,21 @@
+-- Admin cleanups archive the forms matching their criteria in batches,
+-- recording their progress and the last form walked to resume from.
+CREATE TABLE IF NOT EXISTS "form_cleanup_jobs" (
+    "id" uuid,
+    "status" varchar(20) NOT NULL,
+    "criteria" JSONB NOT NULL,
+    "requested_by" varchar(255),
+    "matched" bigint NOT NULL DEFAULT 0,
+    "archived" bigint NOT NULL DEFAULT 0,
+    "failed" bigint NOT NULL DEFAULT 0,
+    "cursor_created_at" timestamptz,
+    "cursor_id" uuid,
+    "error" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "started_at" timestamptz,
+    "finished_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_form_cleanup_jobs_status" ON "form_cleanup_jobs" ("status");
+CREATE INDEX IF NOT EXISTS "idx_forms_created_at_id" ON "forms" ("created_at", "id");
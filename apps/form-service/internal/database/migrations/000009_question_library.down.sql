@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "idx_questions_library_question_id";
+ALTER TABLE "questions" DROP COLUMN IF EXISTS "library_version";
+ALTER TABLE "questions" DROP COLUMN IF EXISTS "library_question_id";
+DROP TABLE IF EXISTS "library_questions";
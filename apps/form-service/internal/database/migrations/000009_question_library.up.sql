@@ -0,0 +1,26 @@
+-- The question bank of an organization. Forms copy library questions,
+-- recording the library question and version each copy was made from.
+CREATE TABLE IF NOT EXISTS "library_questions" (
+    "id" uuid,
+    "organization_id" uuid NOT NULL,
+    "version" bigint NOT NULL DEFAULT 1,
+    "type" varchar(20) NOT NULL,
+    "title" varchar(500) NOT NULL,
+    "description" text,
+    "options" JSONB,
+    "validation" JSONB,
+    "tags" JSONB NOT NULL DEFAULT '[]',
+    "created_by" uuid NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_library_questions_organization" FOREIGN KEY ("organization_id") REFERENCES "organizations"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_library_questions_organization_id" ON "library_questions" ("organization_id");
+CREATE INDEX IF NOT EXISTS "idx_library_questions_deleted_at" ON "library_questions" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_library_questions_tags" ON "library_questions" USING GIN ("tags");
+
+ALTER TABLE "questions" ADD COLUMN IF NOT EXISTS "library_question_id" uuid;
+ALTER TABLE "questions" ADD COLUMN IF NOT EXISTS "library_version" bigint;
+CREATE INDEX IF NOT EXISTS "idx_questions_library_question_id" ON "questions" ("library_question_id");
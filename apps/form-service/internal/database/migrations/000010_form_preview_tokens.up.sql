@@ -0,0 +1,13 @@
+-- Tokens sharing the draft of a form with people without accounts. The
+-- tokens are signed; their records only let them be listed and revoked.
+CREATE TABLE IF NOT EXISTS "form_preview_tokens" (
+    "id" uuid,
+    "form_id" uuid NOT NULL,
+    "created_by" uuid NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "revoked_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_form_preview_tokens_form" FOREIGN KEY ("form_id") REFERENCES "forms"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_form_preview_tokens_form_id" ON "form_preview_tokens" ("form_id");
@@ -0,0 +1,3 @@
+-- Questions may publish the distribution of their answers on the public
+-- results of their form.
+ALTER TABLE "questions" ADD COLUMN IF NOT EXISTS "results_visibility" varchar(20) NOT NULL DEFAULT 'private';
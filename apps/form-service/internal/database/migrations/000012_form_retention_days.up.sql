@@ -0,0 +1,4 @@
+-- Forms may limit how long their responses are kept; NULL keeps them
+-- forever.
+ALTER TABLE "forms" ADD COLUMN IF NOT EXISTS "retention_days" integer
+    CHECK ("retention_days" BETWEEN 1 AND 3650);
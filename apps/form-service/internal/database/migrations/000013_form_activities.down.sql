@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "form_activities";
+DROP FUNCTION IF EXISTS "form_activities_immutable"();
@@ -0,0 +1,25 @@
+-- The activity feed of forms: who changed what in their definition, and
+-- when. Activities are immutable; only the oldest of a form are deleted,
+-- once it has more than the feed keeps.
+CREATE TABLE IF NOT EXISTS "form_activities" (
+    "id" uuid,
+    "form_id" uuid NOT NULL,
+    "actor_id" uuid NOT NULL,
+    "action" text NOT NULL,
+    "target_type" text NOT NULL,
+    "target_id" uuid NOT NULL,
+    "changes" jsonb,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_form_activities_form" FOREIGN KEY ("form_id") REFERENCES "forms"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_form_activities_form_created" ON "form_activities" ("form_id", "created_at" DESC, "id" DESC);
+
+CREATE OR REPLACE FUNCTION "form_activities_immutable"() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'form activities are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "form_activities_no_update" BEFORE UPDATE ON "form_activities"
+    FOR EACH ROW EXECUTE FUNCTION "form_activities_immutable"();
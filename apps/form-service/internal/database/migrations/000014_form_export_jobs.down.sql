@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "idx_file_uploads_form_id_response_id";
+DROP TABLE IF EXISTS "form_export_jobs";
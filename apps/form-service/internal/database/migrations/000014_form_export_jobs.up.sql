@@ -0,0 +1,25 @@
+-- Exports of the responses of a form, optionally bundled in a ZIP archive
+-- with the files attached to them, recording their progress.
+CREATE TABLE IF NOT EXISTS "form_export_jobs" (
+    "id" uuid,
+    "form_id" uuid NOT NULL,
+    "requested_by" uuid NOT NULL,
+    "format" varchar(10) NOT NULL,
+    "include_files" boolean NOT NULL DEFAULT false,
+    "status" varchar(20) NOT NULL,
+    "responses" bigint NOT NULL DEFAULT 0,
+    "bytes_written" bigint NOT NULL DEFAULT 0,
+    "files_written" bigint NOT NULL DEFAULT 0,
+    "files_skipped" bigint NOT NULL DEFAULT 0,
+    "object_key" varchar(500),
+    "error" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "started_at" timestamptz,
+    "finished_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_form_export_jobs_form_id" ON "form_export_jobs" ("form_id");
+CREATE INDEX IF NOT EXISTS "idx_form_export_jobs_status" ON "form_export_jobs" ("status");
+-- Exports list the files attached to the responses of a form
+CREATE INDEX IF NOT EXISTS "idx_file_uploads_form_id_response_id" ON "file_uploads" ("form_id", "response_id");
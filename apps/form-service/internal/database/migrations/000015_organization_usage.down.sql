@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "idx_forms_organization_id_status";
+DROP TABLE IF EXISTS "organization_usage";
+ALTER TABLE "organizations" DROP COLUMN IF EXISTS "plan";
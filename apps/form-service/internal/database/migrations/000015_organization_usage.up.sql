@@ -0,0 +1,16 @@
+-- Plans hold the quotas of organizations
+ALTER TABLE "organizations" ADD COLUMN IF NOT EXISTS "plan" varchar(50) NOT NULL DEFAULT 'free';
+-- Usage of organizations per month, flushed from the metering counters
+CREATE TABLE IF NOT EXISTS "organization_usage" (
+    "organization_id" uuid,
+    "month" varchar(7),
+    "forms_created" bigint NOT NULL DEFAULT 0,
+    "responses" bigint NOT NULL DEFAULT 0,
+    "storage_bytes" bigint NOT NULL DEFAULT 0,
+    "exports" bigint NOT NULL DEFAULT 0,
+    "updated_at" timestamptz,
+    "reconciled_at" timestamptz,
+    PRIMARY KEY ("organization_id", "month")
+);
+-- Active forms are counted per organization for quotas
+CREATE INDEX IF NOT EXISTS "idx_forms_organization_id_status" ON "forms" ("organization_id", "status") WHERE "deleted_at" IS NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "idx_form_export_jobs_organization_id";
+ALTER TABLE "form_export_jobs" DROP COLUMN IF EXISTS "priority";
+ALTER TABLE "form_export_jobs" DROP COLUMN IF EXISTS "organization_id";
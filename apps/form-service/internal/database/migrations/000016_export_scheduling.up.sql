@@ -0,0 +1,6 @@
+-- Export jobs are scheduled fairly across organizations, by priority
+ALTER TABLE "form_export_jobs" ADD COLUMN IF NOT EXISTS "organization_id" uuid;
+ALTER TABLE "form_export_jobs" ADD COLUMN IF NOT EXISTS "priority" varchar(10) NOT NULL DEFAULT 'normal';
+UPDATE "form_export_jobs" SET "organization_id" = "forms"."organization_id"
+    FROM "forms" WHERE "forms"."id" = "form_export_jobs"."form_id" AND "form_export_jobs"."organization_id" IS NULL;
+CREATE INDEX IF NOT EXISTS "idx_form_export_jobs_organization_id" ON "form_export_jobs" ("organization_id");
@@ -0,0 +1,2 @@
+-- Cross-field validation rules of forms
+ALTER TABLE "forms" ADD COLUMN IF NOT EXISTS "rules" jsonb;
@@ -0,0 +1,2 @@
+-- Versions of form definitions, which builder edits are merged against
+ALTER TABLE "forms" ADD COLUMN IF NOT EXISTS "version" integer NOT NULL DEFAULT 1;
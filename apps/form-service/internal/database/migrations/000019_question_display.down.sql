@@ -0,0 +1,2 @@
+ALTER TABLE "questions" DROP COLUMN IF EXISTS "display";
+ALTER TABLE "export_jobs" DROP COLUMN IF EXISTS "include_presented_order";
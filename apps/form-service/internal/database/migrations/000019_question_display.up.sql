@@ -0,0 +1,5 @@
+-- Display settings of choice questions, such as randomized options
+ALTER TABLE "questions" ADD COLUMN IF NOT EXISTS "display" jsonb;
+
+-- Exports may add the order randomized options were presented in
+ALTER TABLE "export_jobs" ADD COLUMN IF NOT EXISTS "include_presented_order" boolean NOT NULL DEFAULT false;
@@ -0,0 +1,22 @@
+-- Slack and Teams channels the events of forms are posted to. Webhook URLs
+-- are sealed with NOTIFICATION_CHANNEL_SECRET.
+CREATE TABLE IF NOT EXISTS "notification_channels" (
+    "id" uuid,
+    "form_id" uuid NOT NULL,
+    "type" varchar(10) NOT NULL,
+    "name" varchar(100) NOT NULL,
+    "webhook_url" text NOT NULL,
+    "webhook_hint" varchar(100) NOT NULL,
+    "event_types" jsonb NOT NULL,
+    "quiet_hours_start" varchar(5) NOT NULL DEFAULT '',
+    "quiet_hours_end" varchar(5) NOT NULL DEFAULT '',
+    "timezone" varchar(64) NOT NULL,
+    "include_answer_summary" boolean NOT NULL DEFAULT false,
+    "enabled" boolean NOT NULL DEFAULT false,
+    "created_by" uuid NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_notification_channels_form" FOREIGN KEY ("form_id") REFERENCES "forms"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_notification_channels_form_id" ON "notification_channels" ("form_id");
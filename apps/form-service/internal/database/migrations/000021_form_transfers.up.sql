@@ -0,0 +1,28 @@
+-- Transfers of forms to another owner or organization, pending until the
+-- target accepts them
+CREATE TABLE IF NOT EXISTS "form_transfers" (
+    "id" uuid,
+    "form_id" uuid NOT NULL,
+    "form_title" varchar(200) NOT NULL,
+    "from_user_id" uuid NOT NULL,
+    "from_organization_id" uuid NOT NULL,
+    "to_user_id" uuid,
+    "to_organization_id" uuid,
+    "status" varchar(20) NOT NULL,
+    "requested_by" uuid NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "resolved_by" uuid,
+    "resolved_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_form_transfers_form" FOREIGN KEY ("form_id") REFERENCES "forms"("id") ON DELETE CASCADE
+);
+-- A form has one pending transfer at a time
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_form_transfers_pending_form" ON "form_transfers" ("form_id") WHERE "status" = 'pending';
+CREATE INDEX IF NOT EXISTS "idx_form_transfers_pending_expiry" ON "form_transfers" ("expires_at") WHERE "status" = 'pending';
+-- Both parties list their transfers
+CREATE INDEX IF NOT EXISTS "idx_form_transfers_from_user_id" ON "form_transfers" ("from_user_id");
+CREATE INDEX IF NOT EXISTS "idx_form_transfers_to_user_id" ON "form_transfers" ("to_user_id");
+CREATE INDEX IF NOT EXISTS "idx_form_transfers_from_organization_id" ON "form_transfers" ("from_organization_id");
+CREATE INDEX IF NOT EXISTS "idx_form_transfers_to_organization_id" ON "form_transfers" ("to_organization_id");
@@ -0,0 +1,27 @@
+-- Bulk deletions of the responses of forms run as jobs deleting a batch at
+-- a time, the batch being deleted saved so a failed job resumes with it
+CREATE TABLE IF NOT EXISTS "response_deletion_jobs" (
+    "id" uuid,
+    "form_id" uuid NOT NULL,
+    "organization_id" uuid NOT NULL,
+    "status" varchar(20) NOT NULL,
+    "filter" JSONB NOT NULL,
+    "confirmation_id" uuid NOT NULL,
+    "requested_by" uuid NOT NULL,
+    "cap_overridden" boolean NOT NULL DEFAULT false,
+    "matched" bigint NOT NULL,
+    "total" bigint NOT NULL,
+    "deleted" bigint NOT NULL DEFAULT 0,
+    "pending" JSONB,
+    "error" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "started_at" timestamptz,
+    "finished_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_response_deletion_jobs_form" FOREIGN KEY ("form_id") REFERENCES "forms"("id") ON DELETE CASCADE
+);
+-- A confirmation token starts a single deletion
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_response_deletion_jobs_confirmation_id" ON "response_deletion_jobs" ("confirmation_id");
+CREATE INDEX IF NOT EXISTS "idx_response_deletion_jobs_form_id" ON "response_deletion_jobs" ("form_id");
+CREATE INDEX IF NOT EXISTS "idx_response_deletion_jobs_status" ON "response_deletion_jobs" ("status");
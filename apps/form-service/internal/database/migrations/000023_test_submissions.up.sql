@@ -0,0 +1,3 @@
+-- Exports leave out the test submissions of owners and collaborators unless
+-- asked to include them
+ALTER TABLE "export_jobs" ADD COLUMN IF NOT EXISTS "include_test" boolean NOT NULL DEFAULT false;
@@ -0,0 +1,4 @@
+ALTER TABLE "export_jobs" DROP COLUMN IF EXISTS "include_labels";
+DROP TABLE IF EXISTS "question_key_aliases";
+DROP INDEX IF EXISTS "idx_questions_form_key";
+ALTER TABLE "questions" DROP COLUMN IF EXISTS "key";
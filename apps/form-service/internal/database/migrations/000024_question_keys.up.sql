@@ -0,0 +1,36 @@
+-- Questions have a stable key, unique within their form, which responses are
+-- projected under. Existing questions get one derived from their title,
+-- suffixed when another question of the form derives the same.
+ALTER TABLE "questions" ADD COLUMN IF NOT EXISTS "key" varchar(64) NOT NULL DEFAULT '';
+
+WITH "derived" AS (
+    SELECT "id", "form_id", "order",
+        COALESCE(NULLIF(TRIM(BOTH '_' FROM LEFT(REGEXP_REPLACE(LOWER("title"), '[^a-z0-9]+', '_', 'g'), 52)), ''), 'question') AS "base"
+    FROM "questions" WHERE "key" = ''
+), "numbered" AS (
+    SELECT "id",
+        CASE WHEN "base" ~ '^[0-9]' THEN 'q_' || "base" ELSE "base" END AS "base",
+        ROW_NUMBER() OVER (PARTITION BY "form_id", "base" ORDER BY "order", "id") AS "n"
+    FROM "derived"
+)
+UPDATE "questions" SET "key" = CASE WHEN "numbered"."n" = 1 THEN "numbered"."base"
+    ELSE "numbered"."base" || '_' || LEFT(REPLACE("questions"."id"::text, '-', ''), 8) END
+FROM "numbered" WHERE "questions"."id" = "numbered"."id";
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_questions_form_key" ON "questions" ("form_id", "key") WHERE "key" <> '' AND "deleted_at" IS NULL;
+
+-- Keys questions were renamed from, still aggregated with the question and
+-- taken from the other questions of the form
+CREATE TABLE IF NOT EXISTS "question_key_aliases" (
+    "form_id" uuid,
+    "key" varchar(64),
+    "question_id" uuid NOT NULL,
+    "renamed_by" uuid NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("form_id", "key"),
+    CONSTRAINT "fk_question_key_aliases_form" FOREIGN KEY ("form_id") REFERENCES "forms"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_question_key_aliases_question_id" ON "question_key_aliases" ("question_id");
+
+-- Exports name their columns by question key, optionally labelled by title
+ALTER TABLE "export_jobs" ADD COLUMN IF NOT EXISTS "include_labels" boolean NOT NULL DEFAULT false;
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"gorm.io/gorm"
+)
+
+// Config bounds the PostgreSQL connection pools and sets the read replicas.
+// Every instance opens up to MaxOpenConns connections to each database, so
+// MaxOpenConns times the number of instances must stay below max_connections
+// of each server.
+type Config struct {
+	// MaxOpenConns caps the connections in use and idle; queries wait for a
+	// connection once it is reached
+	MaxOpenConns int
+	// MaxIdleConns is how many connections are kept open between queries
+	MaxIdleConns int
+	// ConnMaxLifetime recycles connections, so failovers and load balancers
+	// see them move; zero keeps them forever
+	ConnMaxLifetime time.Duration
+	// ConnMaxIdleTime closes connections idle for this long; zero keeps them
+	ConnMaxIdleTime time.Duration
+	// SlowQueryThreshold logs the queries taking longer; zero logs none
+	SlowQueryThreshold time.Duration
+
+	// ReplicaURLs are the streaming replicas of the primary serving the
+	// queries opted in with Replica; none sends every query to the primary
+	ReplicaURLs []string
+	// PrimaryOnly sends every query to the primary even with replicas, to
+	// tell whether replication lag explains a stale read
+	PrimaryOnly bool
+	// ReplicaCheckInterval is how often the health and lag of each replica
+	// are checked
+	ReplicaCheckInterval time.Duration
+	// ReplicaMaxLag takes the replicas replaying WAL further behind the
+	// primary out of rotation
+	ReplicaMaxLag time.Duration
+}
+
+func (c Config) apply(sqlDB *sql.DB) {
+	sqlDB.SetMaxOpenConns(c.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(c.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(c.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(c.ConnMaxIdleTime)
+}
+
+// RegisterMetrics registers the go_sql_* gauges and counters sampled from the
+// pool statistics of db on every scrape: connections in use and idle, and the
+// count and total duration of waits for a connection. The pool of each
+// replica is labelled db_name="form_service_replica_<n>", next to the metrics
+// of the query routing.
+func RegisterMetrics(reg prometheus.Registerer, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database instance: %w", err)
+	}
+	if err := reg.Register(collectors.NewDBStatsCollector(sqlDB, "form_service")); err != nil {
+		return err
+	}
+
+	resolver := resolverOf(db)
+	if resolver == nil {
+		return nil
+	}
+	for _, replica := range resolver.replicas {
+		if err := reg.Register(collectors.NewDBStatsCollector(replica.db, "form_service_"+replica.name)); err != nil {
+			return err
+		}
+	}
+	return resolver.metrics.register(reg)
+}
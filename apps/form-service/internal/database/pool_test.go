@@ -0,0 +1,150 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// slowDriver serves every statement after a delay and records how many
+// connections were busy at once
+type slowDriver struct {
+	delay   time.Duration
+	busy    atomic.Int32
+	maxBusy atomic.Int32
+}
+
+func (d *slowDriver) Open(string) (driver.Conn, error) { return &slowConn{d}, nil }
+
+type slowConn struct{ d *slowDriver }
+
+func (c *slowConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *slowConn) Close() error                        { return nil }
+func (c *slowConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+func (c *slowConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	busy := c.d.busy.Add(1)
+	defer c.d.busy.Add(-1)
+	for {
+		max := c.d.maxBusy.Load()
+		if busy <= max || c.d.maxBusy.CompareAndSwap(max, busy) {
+			break
+		}
+	}
+
+	select {
+	case <-time.After(c.d.delay):
+		return driver.RowsAffected(0), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+var registerSlowDriver sync.Once
+
+func TestPoolCapUnderLoad(t *testing.T) {
+	slow := &slowDriver{delay: 20 * time.Millisecond}
+	registerSlowDriver.Do(func() { sql.Register("form-service-slow", slow) })
+
+	sqlDB, err := sql.Open("form-service-slow", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	cfg := Config{MaxOpenConns: 4, MaxIdleConns: 2}
+	cfg.apply(sqlDB)
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := prometheus.NewRegistry()
+	if err := RegisterMetrics(reg, db); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 40; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := db.Exec("SELECT pg_sleep(0.02)").Error; err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := slow.maxBusy.Load(); got > int32(cfg.MaxOpenConns) {
+		t.Errorf("%d connections busy at once, want at most %d", got, cfg.MaxOpenConns)
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics := make(map[string]float64)
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			if m.GetGauge() != nil {
+				metrics[family.GetName()] = m.GetGauge().GetValue()
+			}
+			if m.GetCounter() != nil {
+				metrics[family.GetName()] = m.GetCounter().GetValue()
+			}
+		}
+	}
+	if got := metrics["go_sql_max_open_connections"]; got != float64(cfg.MaxOpenConns) {
+		t.Errorf("go_sql_max_open_connections = %v, want %d", got, cfg.MaxOpenConns)
+	}
+	if metrics["go_sql_wait_count_total"] == 0 || metrics["go_sql_wait_duration_seconds_total"] == 0 {
+		t.Errorf("waits for a connection were not recorded: %v", metrics)
+	}
+	if got := metrics["go_sql_idle_connections"]; got > float64(cfg.MaxIdleConns) {
+		t.Errorf("go_sql_idle_connections = %v, want at most %d", got, cfg.MaxIdleConns)
+	}
+}
+
+func TestSlowQueryLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewSlowQueryLogger(logger.Discard, 100*time.Millisecond).(*slowQueryLogger)
+	l.log = log.New(&buf, "", 0)
+
+	ctx := context.WithValue(context.Background(), correlationIDKey, "req-42")
+	query := func() (string, int64) { return `SELECT * FROM "forms"`, 3 }
+
+	l.Trace(ctx, time.Now().Add(-10*time.Millisecond), query, nil)
+	if buf.Len() != 0 {
+		t.Fatalf("fast query logged: %s", buf.String())
+	}
+
+	l.Trace(ctx, time.Now().Add(-150*time.Millisecond), query, nil)
+	got := buf.String()
+	for _, want := range []string{"SLOW QUERY", "correlation_id=req-42", "rows=3", `SELECT * FROM "forms"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("log %q does not contain %q", got, want)
+		}
+	}
+
+	// Switching the log level keeps the threshold
+	buf.Reset()
+	l.LogMode(logger.Silent).Trace(context.Background(), time.Now().Add(-time.Second), query, nil)
+	if !strings.Contains(buf.String(), "correlation_id=-") {
+		t.Errorf("log %q after LogMode", buf.String())
+	}
+}
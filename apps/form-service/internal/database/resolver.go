@@ -0,0 +1,367 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sync/atomic"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+)
+
+// Defaults of the replica checks when Config leaves them zero
+const (
+	DefaultReplicaCheckInterval = 5 * time.Second
+	DefaultReplicaMaxLag        = 2 * time.Second
+)
+
+const (
+	// resolverName is the name of the Resolver plugin and the prefix of its
+	// callbacks
+	resolverName = "form_service:resolver"
+	// replicaSetting marks the statements that may read from a replica
+	replicaSetting = "form_service:replica"
+	// routedReplica is the instance key of the replica a statement was
+	// routed to
+	routedReplica = "form_service:routed_replica"
+	// primaryTarget labels the queries served by the primary
+	primaryTarget = "primary"
+)
+
+// replicaLagQuery measures how far behind the primary a replica replays
+// WAL. A replica that replayed all it received is caught up, however long
+// ago the primary last committed.
+const replicaLagQuery = `SELECT COALESCE(CASE
+	WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())
+END, 0)`
+
+// Replica lets the queries of db read from a replica of the primary. Use it
+// for the reads that tolerate replication lag, the form lists and the change
+// feed: the session it returns serves from the primary all the same in a
+// transaction, for a locking read, once the request wrote, and when no
+// replica is healthy.
+func Replica(db *gorm.DB) *gorm.DB {
+	return db.Set(replicaSetting, true).Session(&gorm.Session{})
+}
+
+// session records whether a request wrote to the database
+type session struct {
+	wrote atomic.Bool
+}
+
+type sessionKey struct{}
+
+// WithSession starts the database session of a request. Once a statement
+// of the session wrote, its reads stick to the primary, so a request reads
+// its own writes.
+func WithSession(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sessionKey{}, &session{})
+}
+
+func sessionOf(ctx context.Context) *session {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(sessionKey{}).(*session)
+	return s
+}
+
+// replica is a replica database in the rotation of a Resolver
+type replica struct {
+	name string
+	db   *sql.DB
+
+	healthy atomic.Bool
+	// checkedAt is when the replica was last checked, in Unix nanoseconds
+	checkedAt atomic.Int64
+	checking  atomic.Bool
+}
+
+// Resolver is a GORM plugin routing the queries opted in with Replica to the
+// healthy replicas in turn, and every other statement to the primary.
+// Replicas are checked every ReplicaCheckInterval, when a query is routed;
+// one failing or lagging more than ReplicaMaxLag is out of rotation until a
+// check passes again.
+type Resolver struct {
+	replicas    []*replica
+	primaryOnly bool
+	interval    time.Duration
+	maxLag      time.Duration
+
+	next    atomic.Uint64
+	metrics *resolverMetrics
+	// probe measures the replication lag of a replica
+	probe func(ctx context.Context, db *sql.DB) (time.Duration, error)
+	now   func() time.Time
+}
+
+// newResolver creates the resolver of the replicas dbs, named replica_<n> in
+// the order of cfg.ReplicaURLs
+func newResolver(cfg Config, dbs []*sql.DB) *Resolver {
+	r := &Resolver{
+		primaryOnly: cfg.PrimaryOnly,
+		interval:    cfg.ReplicaCheckInterval,
+		maxLag:      cfg.ReplicaMaxLag,
+		metrics:     newResolverMetrics(),
+		probe:       replicationLag,
+		now:         time.Now,
+	}
+	if r.interval <= 0 {
+		r.interval = DefaultReplicaCheckInterval
+	}
+	if r.maxLag <= 0 {
+		r.maxLag = DefaultReplicaMaxLag
+	}
+	for i, db := range dbs {
+		r.replicas = append(r.replicas, &replica{name: fmt.Sprintf("replica_%d", i), db: db})
+	}
+	return r
+}
+
+// openReplicas opens the replicas of cfg with the pool limits of cfg
+func openReplicas(cfg Config) ([]*sql.DB, error) {
+	dbs := make([]*sql.DB, 0, len(cfg.ReplicaURLs))
+	for i, url := range cfg.ReplicaURLs {
+		db, err := sql.Open("pgx", url)
+		if err != nil {
+			for _, opened := range dbs {
+				opened.Close()
+			}
+			return nil, fmt.Errorf("failed to open replica %d: %w", i, err)
+		}
+		cfg.apply(db)
+		dbs = append(dbs, db)
+	}
+	return dbs, nil
+}
+
+// resolverOf returns the resolver registered on db, nil for none
+func resolverOf(db *gorm.DB) *Resolver {
+	r, _ := db.Config.Plugins[resolverName].(*Resolver)
+	return r
+}
+
+// Name implements gorm.Plugin
+func (r *Resolver) Name() string {
+	return resolverName
+}
+
+// Initialize implements gorm.Plugin, registering the callbacks routing the
+// queries and recording the writes of sessions
+func (r *Resolver) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Query().Before("gorm:query").Register(resolverName+":route", r.route); err != nil {
+		return err
+	}
+	if err := callbacks.Query().After("gorm:query").Register(resolverName+":observe", r.observe); err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("gorm:row").Register(resolverName+":route", r.route); err != nil {
+		return err
+	}
+	if err := callbacks.Row().After("gorm:row").Register(resolverName+":observe", r.observe); err != nil {
+		return err
+	}
+	if err := callbacks.Create().After("gorm:create").Register(resolverName+":wrote", wrote); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:update").Register(resolverName+":wrote", wrote); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().After("gorm:delete").Register(resolverName+":wrote", wrote); err != nil {
+		return err
+	}
+	return callbacks.Raw().After("gorm:raw").Register(resolverName+":wrote", wrote)
+}
+
+// route sends the query of db to a replica when it may read from one
+func (r *Resolver) route(db *gorm.DB) {
+	if db.Error != nil {
+		return
+	}
+	if !r.readsReplica(db) {
+		r.metrics.queries.WithLabelValues(primaryTarget).Inc()
+		return
+	}
+
+	replica := r.pick()
+	if replica == nil {
+		r.metrics.fallbacks.Inc()
+		r.metrics.queries.WithLabelValues(primaryTarget).Inc()
+		return
+	}
+	db.Statement.ConnPool = replica.db
+	db.InstanceSet(routedReplica, replica)
+	r.metrics.queries.WithLabelValues(replica.name).Inc()
+}
+
+// readsReplica tells whether the statement of db may read from a replica
+func (r *Resolver) readsReplica(db *gorm.DB) bool {
+	if r.primaryOnly {
+		return false
+	}
+	if opted, _ := db.Get(replicaSetting); opted != true {
+		return false
+	}
+	if _, inTransaction := db.Statement.ConnPool.(gorm.TxCommitter); inTransaction {
+		return false
+	}
+	if _, locking := db.Statement.Clauses["FOR"]; locking {
+		return false
+	}
+	if s := sessionOf(db.Statement.Context); s != nil && s.wrote.Load() {
+		return false
+	}
+	return true
+}
+
+// pick returns the next healthy replica in turn, nil for none
+func (r *Resolver) pick() *replica {
+	r.refresh()
+
+	n := uint64(len(r.replicas))
+	start := r.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		if replica := r.replicas[(start+i)%n]; replica.healthy.Load() {
+			return replica
+		}
+	}
+	return nil
+}
+
+// refresh checks in the background the replicas last checked longer than
+// the check interval ago
+func (r *Resolver) refresh() {
+	now := r.now()
+	for _, replica := range r.replicas {
+		if now.Sub(time.Unix(0, replica.checkedAt.Load())) < r.interval {
+			continue
+		}
+		if replica.checking.CompareAndSwap(false, true) {
+			go func() {
+				defer replica.checking.Store(false)
+				ctx, cancel := context.WithTimeout(context.Background(), r.interval)
+				defer cancel()
+				r.check(ctx, replica)
+			}()
+		}
+	}
+}
+
+// checkAll checks every replica, before the first queries
+func (r *Resolver) checkAll(ctx context.Context) {
+	for _, replica := range r.replicas {
+		r.check(ctx, replica)
+	}
+}
+
+// check measures the lag of a replica and sets whether it is healthy
+func (r *Resolver) check(ctx context.Context, replica *replica) {
+	lag, err := r.probe(ctx, replica.db)
+	if err == nil && lag > r.maxLag {
+		err = fmt.Errorf("replication lag %s above %s", lag.Round(time.Millisecond), r.maxLag)
+	}
+	replica.checkedAt.Store(r.now().UnixNano())
+	r.metrics.lag.WithLabelValues(replica.name).Set(lag.Seconds())
+	r.setHealthy(replica, err)
+}
+
+// setHealthy takes a replica in or out of rotation, logging the changes
+func (r *Resolver) setHealthy(replica *replica, err error) {
+	healthy := err == nil
+	if replica.healthy.Swap(healthy) != healthy {
+		if healthy {
+			log.Printf("Database %s back in rotation", replica.name)
+		} else {
+			log.Printf("Database %s out of rotation: %v", replica.name, err)
+		}
+	}
+	up := 0.0
+	if healthy {
+		up = 1
+	}
+	r.metrics.up.WithLabelValues(replica.name).Set(up)
+}
+
+// observe takes the replica that served the query of db out of rotation
+// when the connection to it failed, until the next check
+func (r *Resolver) observe(db *gorm.DB) {
+	value, ok := db.InstanceGet(routedReplica)
+	if !ok || !isConnectionError(db.Error) {
+		return
+	}
+	replica := value.(*replica)
+	replica.checkedAt.Store(r.now().UnixNano())
+	r.setHealthy(replica, db.Error)
+}
+
+// isConnectionError tells whether err is a failure to reach the database
+// rather than of the query
+func isConnectionError(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.ErrUnexpectedEOF) || errors.As(err, &netErr)
+}
+
+// wrote records that the session of the statement of db wrote
+func wrote(db *gorm.DB) {
+	if s := sessionOf(db.Statement.Context); s != nil {
+		s.wrote.Store(true)
+	}
+}
+
+// replicationLag measures the replication lag of a replica
+func replicationLag(ctx context.Context, db *sql.DB) (time.Duration, error) {
+	var seconds float64
+	if err := db.QueryRowContext(ctx, replicaLagQuery).Scan(&seconds); err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// resolverMetrics count the queries by database and tell the state of the
+// replicas
+type resolverMetrics struct {
+	queries   *prometheus.CounterVec
+	fallbacks prometheus.Counter
+	up        *prometheus.GaugeVec
+	lag       *prometheus.GaugeVec
+}
+
+func newResolverMetrics() *resolverMetrics {
+	return &resolverMetrics{
+		queries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "form_service_db_queries_total",
+			Help: "Queries by the database serving them, primary or replica_<n>",
+		}, []string{"target"}),
+		fallbacks: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "form_service_db_replica_fallbacks_total",
+			Help: "Queries opted in to a replica served by the primary for want of a healthy replica",
+		}),
+		up: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "form_service_db_replica_up",
+			Help: "Whether a replica is in rotation",
+		}, []string{"replica"}),
+		lag: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "form_service_db_replica_lag_seconds",
+			Help: "Replication lag of a replica at its last check",
+		}, []string{"replica"}),
+	}
+}
+
+func (m *resolverMetrics) register(reg prometheus.Registerer) error {
+	for _, c := range []prometheus.Collector{m.queries, m.fallbacks, m.up, m.lag} {
+		if err := reg.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+)
+
+// recordingDriver serves every statement with no rows and records which
+// database, named by its DSN, served it
+type recordingDriver struct {
+	mu      sync.Mutex
+	served  []string
+	failing map[string]bool
+}
+
+func (d *recordingDriver) Open(name string) (driver.Conn, error) {
+	return &recordingConn{d: d, name: name}, nil
+}
+
+func (d *recordingDriver) serve(name, query string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.failing[name] {
+		return driver.ErrBadConn
+	}
+	d.served = append(d.served, name+": "+query)
+	return nil
+}
+
+// take returns the databases that served the statements since the last call
+func (d *recordingDriver) take() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	targets := make([]string, len(d.served))
+	for i, served := range d.served {
+		targets[i], _, _ = strings.Cut(served, ": ")
+	}
+	d.served = nil
+	return targets
+}
+
+type recordingConn struct {
+	d    *recordingDriver
+	name string
+}
+
+func (c *recordingConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *recordingConn) Close() error                        { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error)           { return recordingTx{}, nil }
+
+func (c *recordingConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	if err := c.d.serve(c.name, query); err != nil {
+		return nil, err
+	}
+	return emptyRows{}, nil
+}
+
+func (c *recordingConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if err := c.d.serve(c.name, query); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+type recordingTx struct{}
+
+func (recordingTx) Commit() error   { return nil }
+func (recordingTx) Rollback() error { return nil }
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string         { return nil }
+func (emptyRows) Close() error              { return nil }
+func (emptyRows) Next([]driver.Value) error { return io.EOF }
+
+var (
+	recording         = &recordingDriver{}
+	registerRecording sync.Once
+)
+
+type item struct {
+	ID   int
+	Name string
+}
+
+// resolverDB opens the primary and replicas of the recording driver with a
+// resolver whose replicas pass their checks unless failing
+func resolverDB(t *testing.T, cfg Config, replicas int, failing map[string]bool) (*gorm.DB, *Resolver) {
+	t.Helper()
+	registerRecording.Do(func() { sql.Register("form-service-recording", recording) })
+	recording.mu.Lock()
+	recording.served, recording.failing = nil, failing
+	recording.mu.Unlock()
+
+	open := func(name string) *sql.DB {
+		sqlDB, err := sql.Open("form-service-recording", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { sqlDB.Close() })
+		return sqlDB
+	}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: open("primary")}), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var dbs []*sql.DB
+	for i := 0; i < replicas; i++ {
+		dbs = append(dbs, open(fmt.Sprintf("replica_%d", i)))
+	}
+	cfg.ReplicaCheckInterval = time.Hour
+	resolver := newResolver(cfg, dbs)
+	resolver.probe = func(_ context.Context, db *sql.DB) (time.Duration, error) {
+		for _, replica := range resolver.replicas {
+			if replica.db == db && failing[replica.name] {
+				return 0, errors.New("connection refused")
+			}
+		}
+		return 0, nil
+	}
+	if err := db.Use(resolver); err != nil {
+		t.Fatal(err)
+	}
+	resolver.checkAll(context.Background())
+	return db, resolver
+}
+
+func TestResolverRouting(t *testing.T) {
+	db, _ := resolverDB(t, Config{}, 2, nil)
+	ctx := context.Background()
+
+	var items []item
+	for i := 0; i < 4; i++ {
+		Replica(db.WithContext(ctx)).Where("name = ?", "a").Find(&items)
+	}
+	if got := recording.take(); strings.Join(got, ",") != "replica_1,replica_0,replica_1,replica_0" {
+		t.Errorf("replica reads served by %v, want the replicas in turn", got)
+	}
+
+	var count int64
+	reader := Replica(db.WithContext(ctx))
+	reader.Model(&item{}).Count(&count)
+	reader.Raw("SELECT 1").Scan(&count)
+	if got := recording.take(); len(got) != 2 || got[0] == "primary" || got[1] == "primary" {
+		t.Errorf("replica count and raw read served by %v, want replicas", got)
+	}
+
+	tests := []struct {
+		name string
+		run  func(ctx context.Context)
+	}{
+		{"read not opted in", func(ctx context.Context) { db.WithContext(ctx).Find(&items) }},
+		{"write", func(ctx context.Context) { Replica(db.WithContext(ctx)).Create(&item{Name: "a"}) }},
+		{"locking read", func(ctx context.Context) {
+			Replica(db.WithContext(ctx)).Clauses(clause.Locking{Strength: "UPDATE"}).Find(&items)
+		}},
+		{"read in a transaction", func(ctx context.Context) {
+			db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+				return Replica(tx).Find(&items).Error
+			})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(ctx)
+			for _, target := range recording.take() {
+				if target != "primary" {
+					t.Errorf("served by %s, want the primary", target)
+				}
+			}
+		})
+	}
+}
+
+func TestResolverReadsOwnWrites(t *testing.T) {
+	db, _ := resolverDB(t, Config{}, 1, nil)
+	ctx := WithSession(context.Background())
+
+	var items []item
+	Replica(db.WithContext(ctx)).Find(&items)
+	db.WithContext(ctx).Model(&item{}).Where("id = ?", 1).Update("name", "b")
+	Replica(db.WithContext(ctx)).Find(&items)
+	if got := recording.take(); strings.Join(got, ",") != "replica_0,primary,primary" {
+		t.Errorf("served by %v, want the reads after the write of the request on the primary", got)
+	}
+
+	// Another request reads from the replica all the same
+	Replica(db.WithContext(WithSession(context.Background()))).Find(&items)
+	if got := recording.take(); strings.Join(got, ",") != "replica_0" {
+		t.Errorf("read of another request served by %v, want replica_0", got)
+	}
+}
+
+func TestResolverFallback(t *testing.T) {
+	db, resolver := resolverDB(t, Config{}, 2, map[string]bool{"replica_0": true})
+	reg := prometheus.NewRegistry()
+	if err := RegisterMetrics(reg, db); err != nil {
+		t.Fatal(err)
+	}
+
+	var items []item
+	for i := 0; i < 3; i++ {
+		Replica(db).Find(&items)
+	}
+	if got := recording.take(); strings.Join(got, ",") != "replica_1,replica_1,replica_1" {
+		t.Errorf("served by %v, want the healthy replica", got)
+	}
+
+	// replica_1 failing a query takes it out of rotation
+	recording.failing["replica_1"] = true
+	Replica(db).Find(&items)
+	delete(recording.failing, "replica_1")
+	Replica(db).Find(&items)
+	if got := recording.take(); strings.Join(got, ",") != "primary" {
+		t.Errorf("served by %v, want the primary with no replica in rotation", got)
+	}
+
+	// A passing check brings replicas back
+	delete(recording.failing, "replica_0")
+	resolver.checkAll(context.Background())
+	Replica(db).Find(&items)
+	if got := recording.take(); len(got) != 1 || got[0] == "primary" {
+		t.Errorf("served by %v, want a replica back in rotation", got)
+	}
+
+	if got := testutil.ToFloat64(resolver.metrics.fallbacks); got != 1 {
+		t.Errorf("fallbacks = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(resolver.metrics.queries.WithLabelValues("replica_1")); got < 4 {
+		t.Errorf("replica_1 queries = %v, want at least 4", got)
+	}
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pools := make(map[string]bool)
+	for _, family := range families {
+		if family.GetName() != "go_sql_max_open_connections" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				pools[label.GetValue()] = true
+			}
+		}
+	}
+	for _, want := range []string{"form_service", "form_service_replica_0", "form_service_replica_1"} {
+		if !pools[want] {
+			t.Errorf("no pool metrics of %s in %v", want, pools)
+		}
+	}
+}
+
+func TestResolverLag(t *testing.T) {
+	db, resolver := resolverDB(t, Config{ReplicaMaxLag: time.Second}, 1, nil)
+	resolver.probe = func(context.Context, *sql.DB) (time.Duration, error) { return 3 * time.Second, nil }
+	resolver.checkAll(context.Background())
+
+	var items []item
+	Replica(db).Find(&items)
+	if got := recording.take(); strings.Join(got, ",") != "primary" {
+		t.Errorf("served by %v, want the primary while the replica lags", got)
+	}
+	if got := testutil.ToFloat64(resolver.metrics.lag.WithLabelValues("replica_0")); got != 3 {
+		t.Errorf("lag = %v, want 3", got)
+	}
+}
+
+func TestResolverPrimaryOnly(t *testing.T) {
+	db, _ := resolverDB(t, Config{PrimaryOnly: true}, 2, nil)
+
+	var items []item
+	Replica(db).Find(&items)
+	Replica(db).Find(&items)
+	if got := recording.take(); strings.Join(got, ",") != "primary,primary" {
+		t.Errorf("served by %v, want the primary only", got)
+	}
+}
@@ -0,0 +1,44 @@
+// Package download signs the links that download export artifacts without
+// authentication. A link carries a token signed with signedtoken. The claims
+// bind the token to one artifact until it expires; single-use tokens are
+// also redeemed by the form service, so that they can be used once.
+package download
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/signedtoken"
+)
+
+// ErrInvalidToken is returned for tokens that are malformed, not signed with
+// the secret or expired
+var ErrInvalidToken = errors.New("invalid download token")
+
+// Claims are the claims of a download token
+type Claims struct {
+	// LinkID tells links apart, so single-use links can be redeemed
+	LinkID uuid.UUID `json:"jti"`
+	JobID  uuid.UUID `json:"job_id"`
+	// Key is the path of the artifact in storage
+	Key       string `json:"key"`
+	SingleUse bool   `json:"single_use,omitempty"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Sign returns the token of claims signed with secret
+func Sign(secret string, claims Claims) (string, error) {
+	return signedtoken.Sign(secret, claims)
+}
+
+// Verify returns the claims of a token signed with secret that has not
+// expired at now
+func Verify(secret, token string, now time.Time) (Claims, error) {
+	claims, err := signedtoken.Verify[Claims](secret, token)
+	if err != nil || claims.JobID == uuid.Nil || claims.Key == "" || now.Unix() >= claims.ExpiresAt {
+		return Claims{}, ErrInvalidToken
+	}
+	return claims, nil
+}
@@ -0,0 +1,58 @@
+package download
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSignVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	claims := Claims{
+		LinkID:    uuid.MustParse("0b9f1c2e-3d4a-4b5c-8d6e-7f8091a2b3c4"),
+		JobID:     uuid.MustParse("6f1c2d3e-4a5b-4c6d-8e7f-901a2b3c4d5e"),
+		Key:       "exports/forms/6f1c2d3e/export.zip",
+		SingleUse: true,
+		ExpiresAt: now.Add(5 * time.Minute).Unix(),
+	}
+
+	token, err := Sign("secret", claims)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	got, err := Verify("secret", token, now)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if got != claims {
+		t.Errorf("claims = %+v, want %+v", got, claims)
+	}
+
+	// Pointing the token at another artifact breaks its signature
+	encoded, sig, _ := strings.Cut(token, ".")
+	payload, _ := base64.RawURLEncoding.DecodeString(encoded)
+	var forged Claims
+	json.Unmarshal(payload, &forged)
+	forged.Key = "exports/forms/other/export.zip"
+	forgedPayload, _ := json.Marshal(forged)
+	otherArtifact := base64.RawURLEncoding.EncodeToString(forgedPayload) + "." + sig
+
+	for name, tc := range map[string]struct {
+		secret, token string
+		now           time.Time
+	}{
+		"other secret":   {"other", token, now},
+		"expired":        {"secret", token, now.Add(5 * time.Minute)},
+		"other artifact": {"secret", otherArtifact, now},
+		"malformed":      {"secret", "not-a-token", now},
+	} {
+		if _, err := Verify(tc.secret, tc.token, tc.now); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: error = %v, want ErrInvalidToken", name, err)
+		}
+	}
+}
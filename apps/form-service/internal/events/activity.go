@@ -0,0 +1,68 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+)
+
+// ActivityChannel returns the Redis channel the activity of a form is
+// published to. The collaboration service pushes it to the builders open on
+// the form as activity frames; the channel is shared with it and not
+// prefixed.
+func ActivityChannel(formID string) string {
+	return "form:" + formID + ":activity"
+}
+
+// ActivityPublisher pushes the activity of forms, once recorded, to the
+// builders open on them
+type ActivityPublisher interface {
+	PublishActivity(ctx context.Context, activity *models.FormActivity) error
+}
+
+// RedisActivityPublisher publishes activities to the Redis channels of their
+// forms. Builders that are not connected miss them and read the feed when
+// they open.
+type RedisActivityPublisher struct {
+	client *redis.Client
+}
+
+// NewRedisActivityPublisher creates a publisher of the channels of client
+func NewRedisActivityPublisher(client *redis.Client) *RedisActivityPublisher {
+	return &RedisActivityPublisher{client: client}
+}
+
+// PublishActivity publishes activity as JSON
+func (p *RedisActivityPublisher) PublishActivity(ctx context.Context, activity *models.FormActivity) error {
+	payload, err := json.Marshal(activity)
+	if err != nil {
+		return fmt.Errorf("failed to encode activity: %w", err)
+	}
+	return p.client.Publish(ctx, ActivityChannel(activity.FormID.String()), payload).Err()
+}
+
+// PatchChannel returns the Redis channel the patches merged into a form are
+// published to. The collaboration service pushes them to the builders open on
+// the form as form:patch frames.
+func PatchChannel(formID string) string {
+	return "form:" + formID + ":patches"
+}
+
+// PatchPublisher pushes the patches merged into forms to the builders open
+// on them, so they update live instead of reading the form again
+type PatchPublisher interface {
+	PublishPatch(ctx context.Context, patch *models.FormPatch) error
+}
+
+// PublishPatch publishes patch as JSON
+func (p *RedisActivityPublisher) PublishPatch(ctx context.Context, patch *models.FormPatch) error {
+	payload, err := json.Marshal(patch)
+	if err != nil {
+		return fmt.Errorf("failed to encode patch: %w", err)
+	}
+	return p.client.Publish(ctx, PatchChannel(patch.FormID.String()), payload).Err()
+}
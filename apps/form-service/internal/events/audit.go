@@ -0,0 +1,239 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Audit actions recorded by the form service
+const (
+	AuditFormPublished       = "form.published"
+	AuditFormDeleted         = "form.deleted"
+	AuditFormArchived        = "form.archived"
+	AuditCollaboratorAdded   = "form.collaborator.added"
+	AuditCollaboratorUpdated = "form.collaborator.updated"
+	AuditCollaboratorRemoved = "form.collaborator.removed"
+	AuditPreviewTokenIssued  = "form.preview_token.issued"
+	AuditPreviewTokenRevoked = "form.preview_token.revoked"
+	// Transfers are recorded when requested, accepted and cancelled
+	AuditTransferRequested = "form.transfer.requested"
+	AuditFormTransferred   = "form.transferred"
+	AuditTransferCancelled = "form.transfer.cancelled"
+	// Bulk deletions of responses are recorded when confirmed, and when
+	// they complete or fail
+	AuditResponseDeletionStarted = "form.responses.deletion_started"
+	AuditResponsesDeleted        = "form.responses.deleted"
+
+	AuditOrganizationMemberAdded = "organization.member.added"
+
+	AuditLibraryQuestionPropagated = "library.question.propagated"
+)
+
+// auditAttempts is how many times an audit event is posted before it is
+// counted as failed
+const auditAttempts = 3
+
+// AuditEvent is a security-relevant action on a resource, published as
+// audit.<action> to the audit topic
+type AuditEvent struct {
+	Action       string
+	Actor        string
+	ResourceType string
+	ResourceID   string
+	// Before and After summarize the resource around the action; either may
+	// be nil
+	Before interface{}
+	After  interface{}
+}
+
+// Auditor records audit events. Recording never blocks or fails the action.
+type Auditor interface {
+	Record(ctx context.Context, event AuditEvent)
+}
+
+// NewAuditor returns an auditor publishing to the audit topic of the event
+// bus at eventBusURL, or one that only logs events when no URL is configured
+func NewAuditor(eventBusURL, topic string, bufferSize int, reg prometheus.Registerer) Auditor {
+	if eventBusURL == "" {
+		return LogAuditor{}
+	}
+	auditor := NewHTTPAuditor(eventBusURL, topic, bufferSize, NewAuditMetrics(reg))
+	auditor.Start()
+	return auditor
+}
+
+// auditRequestKey carries the AuditRequest of a request in its context
+type auditRequestKey struct{}
+
+// AuditRequest is what the audit log keeps of the request an action came in
+type AuditRequest struct {
+	IP            string
+	CorrelationID string
+}
+
+// WithAuditRequest returns ctx carrying the request details audit events
+// recorded under it are stamped with
+func WithAuditRequest(ctx context.Context, req AuditRequest) context.Context {
+	return context.WithValue(ctx, auditRequestKey{}, req)
+}
+
+func auditRequestFrom(ctx context.Context) AuditRequest {
+	req, _ := ctx.Value(auditRequestKey{}).(AuditRequest)
+	return req
+}
+
+// AuditMetrics counts audit event deliveries
+type AuditMetrics struct {
+	Events      *prometheus.CounterVec
+	BufferDepth prometheus.Gauge
+}
+
+// NewAuditMetrics creates and registers the audit metrics
+func NewAuditMetrics(reg prometheus.Registerer) *AuditMetrics {
+	m := &AuditMetrics{
+		Events: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "form_service_audit_events_total",
+			Help: "Audit events by outcome (delivered, failed, dropped)",
+		}, []string{"outcome"}),
+		BufferDepth: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "form_service_audit_buffer_depth",
+			Help: "Audit events waiting to be published",
+		}),
+	}
+	if reg != nil {
+		reg.MustRegister(m.Events, m.BufferDepth)
+	}
+	return m
+}
+
+// queuedAuditEvent is an audit event stamped when it was recorded
+type queuedAuditEvent struct {
+	AuditEvent
+	request    AuditRequest
+	occurredAt time.Time
+}
+
+// HTTPAuditor queues audit events and posts them to the event bus's /events
+// endpoint in the background. Events recorded while the queue is full are
+// dropped and counted.
+type HTTPAuditor struct {
+	url     string
+	topic   string
+	client  *http.Client
+	backoff time.Duration
+	metrics *AuditMetrics
+	queue   chan queuedAuditEvent
+}
+
+// NewHTTPAuditor creates an auditor queueing up to bufferSize events. Start
+// starts publishing them.
+func NewHTTPAuditor(eventBusURL, topic string, bufferSize int, metrics *AuditMetrics) *HTTPAuditor {
+	return &HTTPAuditor{
+		url:     strings.TrimSuffix(eventBusURL, "/") + "/events",
+		topic:   topic,
+		client:  &http.Client{Timeout: 5 * time.Second},
+		backoff: 500 * time.Millisecond,
+		metrics: metrics,
+		queue:   make(chan queuedAuditEvent, bufferSize),
+	}
+}
+
+// Record queues the event without waiting
+func (a *HTTPAuditor) Record(ctx context.Context, event AuditEvent) {
+	queued := queuedAuditEvent{AuditEvent: event, request: auditRequestFrom(ctx), occurredAt: time.Now().UTC()}
+	select {
+	case a.queue <- queued:
+		a.metrics.BufferDepth.Set(float64(len(a.queue)))
+	default:
+		a.metrics.Events.WithLabelValues("dropped").Inc()
+		log.Printf("Audit queue full, dropped %s by %s on %s %s", event.Action, event.Actor, event.ResourceType, event.ResourceID)
+	}
+}
+
+// Start starts publishing queued events
+func (a *HTTPAuditor) Start() {
+	go func() {
+		for event := range a.queue {
+			a.metrics.BufferDepth.Set(float64(len(a.queue)))
+			a.publish(event)
+		}
+	}()
+}
+
+// publish posts event, retrying failed attempts. The event ID is kept across
+// attempts, so a retry the event bus already accepted is logged once.
+func (a *HTTPAuditor) publish(event queuedAuditEvent) {
+	body, err := json.Marshal(map[string]interface{}{
+		"id":         "audit_" + uuid.NewString(),
+		"event_type": "audit." + event.Action,
+		"source":     eventSource,
+		"subject":    event.Actor,
+		"topic":      a.topic,
+		"key":        event.Actor,
+		"data": map[string]interface{}{
+			"actor":          event.Actor,
+			"resource_type":  event.ResourceType,
+			"resource_id":    event.ResourceID,
+			"before":         event.Before,
+			"after":          event.After,
+			"ip":             event.request.IP,
+			"correlation_id": event.request.CorrelationID,
+			"occurred_at":    event.occurredAt,
+		},
+	})
+	if err != nil {
+		a.metrics.Events.WithLabelValues("failed").Inc()
+		log.Printf("Failed to encode audit event %s: %v", event.Action, err)
+		return
+	}
+
+	for attempt := 1; ; attempt++ {
+		if err = a.post(body); err == nil {
+			a.metrics.Events.WithLabelValues("delivered").Inc()
+			return
+		}
+		if attempt == auditAttempts {
+			break
+		}
+		time.Sleep(time.Duration(attempt) * a.backoff)
+	}
+	a.metrics.Events.WithLabelValues("failed").Inc()
+	log.Printf("Failed to publish audit event %s by %s on %s %s: %v", event.Action, event.Actor, event.ResourceType, event.ResourceID, err)
+}
+
+func (a *HTTPAuditor) post(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, a.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("event bus answered %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// LogAuditor logs audit events instead of publishing them. It is used when
+// no event bus is configured.
+type LogAuditor struct{}
+
+// Record logs the event
+func (LogAuditor) Record(ctx context.Context, event AuditEvent) {
+	log.Printf("Audit %s by %s on %s %s", event.Action, event.Actor, event.ResourceType, event.ResourceID)
+}
@@ -0,0 +1,119 @@
+// Package events publishes domain events from the form service to the event bus
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Event types emitted by the form service
+const (
+	FileUploaded    = "file.uploaded"
+	FileQuarantined = "file.quarantined"
+	FormPublished   = "form.published"
+	// NotificationTest asks the notification worker for a test email to the
+	// owner of a form
+	NotificationTest = "form.notification.test"
+	// NotificationChannelTest asks the notification worker for a test
+	// message to a Slack or Teams channel of a form
+	NotificationChannelTest = "form.notification.channel_test"
+	// ReportReady asks the notification worker to email a scheduled report
+	// to its recipients
+	ReportReady = "form.report.ready"
+	// FormThrottleEngaged and FormThrottleReleased tell the owner of a form
+	// that a surge of submissions throttled it, and that it normalized
+	FormThrottleEngaged  = "form.throttle.engaged"
+	FormThrottleReleased = "form.throttle.released"
+	// FormArchived tells caches of a form that an admin cleanup exported and
+	// deleted it
+	FormArchived = "form.archived"
+	// FormUpdated and FormDeleted tell caches of a form that its definition
+	// changed, or that it is gone
+	FormUpdated = "form.updated"
+	FormDeleted = "form.deleted"
+	// FormTransferred tells caches of a form that it moved to another owner
+	// or organization
+	FormTransferred = "form.transferred"
+	// ResponsesDeleted tells the projections of the responses of a form
+	// that a batch of a bulk deletion was deleted
+	ResponsesDeleted = "form.responses.deleted"
+	// FunnelStage tells the funnel projection that a session of a public
+	// form reached a stage, once per session and stage
+	FunnelStage = "form.funnel.stage"
+)
+
+// eventSource identifies the form service as the producer of an event
+const eventSource = "form-service"
+
+// Publisher sends events to the event bus
+type Publisher interface {
+	Publish(ctx context.Context, eventType, key string, data map[string]interface{}) error
+}
+
+// NewPublisher returns an HTTP publisher for eventBusURL, or a publisher that
+// only logs events when no URL is configured
+func NewPublisher(eventBusURL string) Publisher {
+	if eventBusURL == "" {
+		return LogPublisher{}
+	}
+	return NewHTTPPublisher(eventBusURL)
+}
+
+// HTTPPublisher posts events to the event bus service's /events endpoint
+type HTTPPublisher struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPPublisher creates a new HTTP event publisher
+func NewHTTPPublisher(eventBusURL string) *HTTPPublisher {
+	return &HTTPPublisher{
+		url:    strings.TrimSuffix(eventBusURL, "/") + "/events",
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Publish sends a single event
+func (p *HTTPPublisher) Publish(ctx context.Context, eventType, key string, data map[string]interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"event_type": eventType,
+		"source":     eventSource,
+		"key":        key,
+		"data":       data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to publish %s event: %w", eventType, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("event bus rejected %s event with status %d", eventType, resp.StatusCode)
+	}
+	return nil
+}
+
+// LogPublisher logs events instead of sending them. It is used when no event bus is configured.
+type LogPublisher struct{}
+
+// Publish logs the event
+func (LogPublisher) Publish(ctx context.Context, eventType, key string, data map[string]interface{}) error {
+	log.Printf("Event %s (key=%s): %v", eventType, key, data)
+	return nil
+}
@@ -0,0 +1,626 @@
+// Package expr evaluates the expressions of the cross-field validation rules
+// of forms. The language is deliberately small: number, string and boolean
+// literals, variables holding the answers to questions, arithmetic,
+// comparisons, logic and the functions answered and count. Nothing else can
+// be expressed: there are no loops, assignments, member accesses or calls
+// outside of the two functions. Expressions are bounded in length, nesting
+// and size, and type checked before they are saved, so that the expression
+// of a form owner can neither run code nor exhaust the service.
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MaxLength bounds the source of an expression, in bytes
+	MaxLength = 500
+	// MaxDepth bounds the nesting of parentheses and unary operators
+	MaxDepth = 16
+	// MaxNodes bounds the operands and operators of an expression, which
+	// also bounds the recursion evaluating it
+	MaxNodes = 100
+)
+
+var (
+	// ErrInvalid is returned for expressions that don't parse or type check
+	ErrInvalid = errors.New("invalid expression")
+	// ErrUnanswered is returned when evaluating an expression reads the
+	// answer to a question that was not answered
+	ErrUnanswered = errors.New("expression reads an unanswered question")
+	// ErrDivisionByZero is returned when evaluating an expression divides by
+	// zero
+	ErrDivisionByZero = errors.New("division by zero")
+)
+
+// Type is the type of a value
+type Type int
+
+const (
+	Number Type = iota + 1
+	String
+	Bool
+	// List is the type of the options selected in a checkbox question
+	List
+)
+
+func (t Type) String() string {
+	switch t {
+	case Number:
+		return "number"
+	case String:
+		return "string"
+	case Bool:
+		return "boolean"
+	case List:
+		return "list"
+	}
+	return "unknown"
+}
+
+// Value is the value of a variable or of an expression. Variables holding
+// the answer to an unanswered question are null.
+type Value struct {
+	typ  Type
+	null bool
+	num  float64
+	str  string
+	b    bool
+	list []string
+}
+
+// NumberValue returns a number
+func NumberValue(v float64) Value { return Value{typ: Number, num: v} }
+
+// StringValue returns a string
+func StringValue(v string) Value { return Value{typ: String, str: v} }
+
+// BoolValue returns a boolean
+func BoolValue(v bool) Value { return Value{typ: Bool, b: v} }
+
+// ListValue returns a list
+func ListValue(v []string) Value { return Value{typ: List, list: v} }
+
+// Null returns the value of an unanswered question of type t
+func Null(t Type) Value { return Value{typ: t, null: true} }
+
+// IsNull reports whether the value is that of an unanswered question
+func (v Value) IsNull() bool { return v.null }
+
+// Number returns the number a value holds, if it holds one
+func (v Value) Number() (float64, bool) { return v.num, v.typ == Number && !v.null }
+
+// Reserved reports whether name is a keyword or function, which can't name
+// a variable
+func Reserved(name string) bool {
+	switch name {
+	case "true", "false", "answered", "count":
+		return true
+	}
+	return false
+}
+
+// Expr is a parsed expression
+type Expr struct {
+	root node
+	vars []string
+}
+
+// Parse parses src. Parse bounds the expression but does not type check it;
+// see Check.
+func Parse(src string) (*Expr, error) {
+	if len(src) > MaxLength {
+		return nil, fmt.Errorf("%w: longer than %d characters", ErrInvalid, MaxLength)
+	}
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, vars: make(map[string]bool)}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("%w: unexpected %q", ErrInvalid, p.tokens[p.pos].text)
+	}
+
+	e := &Expr{root: root}
+	for name := range p.vars {
+		e.vars = append(e.vars, name)
+	}
+	sort.Strings(e.vars)
+	return e, nil
+}
+
+// Variables returns the names of the variables the expression reads, sorted
+func (e *Expr) Variables() []string {
+	return append([]string(nil), e.vars...)
+}
+
+// Check type checks the expression against the types of its variables. The
+// expression must be a condition, of type Bool.
+func (e *Expr) Check(types map[string]Type) error {
+	t, err := e.root.check(types)
+	if err != nil {
+		return err
+	}
+	if t != Bool {
+		return fmt.Errorf("%w: the expression is a %s, not a condition", ErrInvalid, t)
+	}
+	return nil
+}
+
+// Eval evaluates the checked expression with the values of its variables.
+// It fails with ErrUnanswered when it reads a null value, except through
+// answered and count, and with ErrDivisionByZero.
+func (e *Expr) Eval(vars map[string]Value) (bool, error) {
+	v, err := e.root.eval(vars)
+	if err != nil {
+		return false, err
+	}
+	if v.typ != Bool {
+		return false, fmt.Errorf("%w: the expression is a %s, not a condition", ErrInvalid, v.typ)
+	}
+	return v.b, nil
+}
+
+// node is a node of the syntax tree
+type node interface {
+	check(types map[string]Type) (Type, error)
+	eval(vars map[string]Value) (Value, error)
+}
+
+type literal struct {
+	v Value
+}
+
+func (n literal) check(map[string]Type) (Type, error) { return n.v.typ, nil }
+
+func (n literal) eval(map[string]Value) (Value, error) { return n.v, nil }
+
+type variable struct {
+	name string
+}
+
+func (n variable) check(types map[string]Type) (Type, error) {
+	t, ok := types[n.name]
+	if !ok {
+		return 0, fmt.Errorf("%w: unknown question %q", ErrInvalid, n.name)
+	}
+	return t, nil
+}
+
+func (n variable) eval(vars map[string]Value) (Value, error) {
+	v, ok := vars[n.name]
+	if !ok || v.null {
+		return Value{}, ErrUnanswered
+	}
+	return v, nil
+}
+
+// call is a call of answered or count, whose argument is a variable
+type call struct {
+	fn  string
+	arg string
+}
+
+func (n call) check(types map[string]Type) (Type, error) {
+	t, ok := types[n.arg]
+	if !ok {
+		return 0, fmt.Errorf("%w: unknown question %q", ErrInvalid, n.arg)
+	}
+	if n.fn == "count" {
+		if t != List {
+			return 0, fmt.Errorf("%w: count takes a checkbox question, not a %s", ErrInvalid, t)
+		}
+		return Number, nil
+	}
+	return Bool, nil
+}
+
+func (n call) eval(vars map[string]Value) (Value, error) {
+	v, ok := vars[n.arg]
+	answered := ok && !v.null
+	if n.fn == "count" {
+		if !answered {
+			return NumberValue(0), nil
+		}
+		return NumberValue(float64(len(v.list))), nil
+	}
+	return BoolValue(answered), nil
+}
+
+type unary struct {
+	op string
+	x  node
+}
+
+func (n unary) check(types map[string]Type) (Type, error) {
+	t, err := n.x.check(types)
+	if err != nil {
+		return 0, err
+	}
+	want := Number
+	if n.op == "!" {
+		want = Bool
+	}
+	if t != want {
+		return 0, fmt.Errorf("%w: %s applied to a %s", ErrInvalid, n.op, t)
+	}
+	return t, nil
+}
+
+func (n unary) eval(vars map[string]Value) (Value, error) {
+	v, err := n.x.eval(vars)
+	if err != nil {
+		return Value{}, err
+	}
+	if n.op == "!" {
+		return BoolValue(!v.b), nil
+	}
+	return NumberValue(-v.num), nil
+}
+
+type binary struct {
+	op   string
+	x, y node
+}
+
+func (n binary) check(types map[string]Type) (Type, error) {
+	x, err := n.x.check(types)
+	if err != nil {
+		return 0, err
+	}
+	y, err := n.y.check(types)
+	if err != nil {
+		return 0, err
+	}
+	mismatch := fmt.Errorf("%w: %s between a %s and a %s", ErrInvalid, n.op, x, y)
+
+	switch n.op {
+	case "&&", "||":
+		if x != Bool || y != Bool {
+			return 0, mismatch
+		}
+		return Bool, nil
+	case "==", "!=":
+		if x != y || x == List {
+			return 0, mismatch
+		}
+		return Bool, nil
+	case "<", "<=", ">", ">=":
+		if x != y || (x != Number && x != String) {
+			return 0, mismatch
+		}
+		return Bool, nil
+	default:
+		if x != Number || y != Number {
+			return 0, mismatch
+		}
+		return Number, nil
+	}
+}
+
+func (n binary) eval(vars map[string]Value) (Value, error) {
+	x, err := n.x.eval(vars)
+	if err != nil {
+		return Value{}, err
+	}
+	// Logic short-circuits, so answered(q) && q > 0 reads q when answered
+	switch {
+	case n.op == "&&" && !x.b:
+		return BoolValue(false), nil
+	case n.op == "||" && x.b:
+		return BoolValue(true), nil
+	}
+	y, err := n.y.eval(vars)
+	if err != nil {
+		return Value{}, err
+	}
+
+	switch n.op {
+	case "&&", "||":
+		return BoolValue(y.b), nil
+	case "==":
+		return BoolValue(equal(x, y)), nil
+	case "!=":
+		return BoolValue(!equal(x, y)), nil
+	case "<", "<=", ">", ">=":
+		c := compare(x, y)
+		return BoolValue(n.op == "<" && c < 0 || n.op == "<=" && c <= 0 || n.op == ">" && c > 0 || n.op == ">=" && c >= 0), nil
+	case "+":
+		return NumberValue(x.num + y.num), nil
+	case "-":
+		return NumberValue(x.num - y.num), nil
+	case "*":
+		return NumberValue(x.num * y.num), nil
+	default:
+		if y.num == 0 {
+			return Value{}, ErrDivisionByZero
+		}
+		return NumberValue(x.num / y.num), nil
+	}
+}
+
+// numberTolerance absorbs the rounding of decimal fractions, so that
+// 33.3 + 33.3 + 33.4 == 100
+const numberTolerance = 1e-9
+
+func equal(x, y Value) bool {
+	switch x.typ {
+	case Number:
+		return math.Abs(x.num-y.num) <= numberTolerance*math.Max(1, math.Abs(y.num))
+	case String:
+		return x.str == y.str
+	default:
+		return x.b == y.b
+	}
+}
+
+// compare orders numbers, and strings lexically, which orders ISO 8601
+// dates such as 2024-05-01 chronologically
+func compare(x, y Value) int {
+	if x.typ == String {
+		return strings.Compare(x.str, y.str)
+	}
+	switch {
+	case equal(x, y):
+		return 0
+	case x.num < y.num:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// token is a token of an expression
+type token struct {
+	kind byte // 'n'umber, 's'tring, 'i'dentifier or 'o'perator
+	text string
+}
+
+// operators are the operators of the language, longest first
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "+", "-", "*", "/", "(", ")"}
+
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c >= '0' && c <= '9':
+			start := i
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{'n', src[start:i]})
+		case c == '"':
+			var b strings.Builder
+			i++
+			for ; i < len(src) && src[i] != '"'; i++ {
+				if src[i] == '\\' {
+					i++
+					if i == len(src) || (src[i] != '"' && src[i] != '\\') {
+						return nil, fmt.Errorf("%w: only \\\" and \\\\ escapes are allowed in strings", ErrInvalid)
+					}
+				}
+				b.WriteByte(src[i])
+			}
+			if i == len(src) {
+				return nil, fmt.Errorf("%w: unterminated string", ErrInvalid)
+			}
+			i++
+			tokens = append(tokens, token{'s', b.String()})
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			start := i
+			for i < len(src) && (src[i] == '_' || src[i] >= 'a' && src[i] <= 'z' || src[i] >= 'A' && src[i] <= 'Z' || src[i] >= '0' && src[i] <= '9') {
+				i++
+			}
+			tokens = append(tokens, token{'i', src[start:i]})
+		default:
+			op := ""
+			for _, candidate := range operators {
+				if strings.HasPrefix(src[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("%w: unexpected character %q", ErrInvalid, c)
+			}
+			tokens = append(tokens, token{'o', op})
+			i += len(op)
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("%w: empty expression", ErrInvalid)
+	}
+	return tokens, nil
+}
+
+// parser is a recursive descent parser of:
+//
+//	or      = and { "||" and }
+//	and     = cmp { "&&" cmp }
+//	cmp     = add [ ( "==" | "!=" | "<" | "<=" | ">" | ">=" ) add ]
+//	add     = mul { ( "+" | "-" ) mul }
+//	mul     = unary { ( "*" | "/" ) unary }
+//	unary   = ( "!" | "-" ) unary | primary
+//	primary = number | string | "true" | "false" | name
+//	        | ( "answered" | "count" ) "(" name ")" | "(" or ")"
+type parser struct {
+	tokens []token
+	pos    int
+	depth  int
+	nodes  int
+	vars   map[string]bool
+}
+
+func (p *parser) peek(ops ...string) (string, bool) {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != 'o' {
+		return "", false
+	}
+	for _, op := range ops {
+		if p.tokens[p.pos].text == op {
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) expect(op string) error {
+	if _, ok := p.peek(op); !ok {
+		return fmt.Errorf("%w: expected %q", ErrInvalid, op)
+	}
+	p.pos++
+	return nil
+}
+
+// add counts a node against MaxNodes
+func (p *parser) add(n node) (node, error) {
+	p.nodes++
+	if p.nodes > MaxNodes {
+		return nil, fmt.Errorf("%w: more than %d operands and operators", ErrInvalid, MaxNodes)
+	}
+	return n, nil
+}
+
+func (p *parser) nest() error {
+	p.depth++
+	if p.depth > MaxDepth {
+		return fmt.Errorf("%w: nested deeper than %d levels", ErrInvalid, MaxDepth)
+	}
+	return nil
+}
+
+// binaryLevel parses operands joined by ops, left to right. Comparisons
+// don't chain, so a < b < c is rejected.
+func (p *parser) binaryLevel(operand func() (node, error), chain bool, ops ...string) (node, error) {
+	x, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.peek(ops...)
+		if !ok {
+			return x, nil
+		}
+		p.pos++
+		y, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		if x, err = p.add(binary{op: op, x: x, y: y}); err != nil {
+			return nil, err
+		}
+		if !chain {
+			if _, ok := p.peek(ops...); ok {
+				return nil, fmt.Errorf("%w: comparisons can't be chained", ErrInvalid)
+			}
+			return x, nil
+		}
+	}
+}
+
+func (p *parser) parseOr() (node, error) {
+	return p.binaryLevel(p.parseAnd, true, "||")
+}
+
+func (p *parser) parseAnd() (node, error) {
+	return p.binaryLevel(p.parseComparison, true, "&&")
+}
+
+func (p *parser) parseComparison() (node, error) {
+	return p.binaryLevel(p.parseAdd, false, "==", "!=", "<=", ">=", "<", ">")
+}
+
+func (p *parser) parseAdd() (node, error) {
+	return p.binaryLevel(p.parseMul, true, "+", "-")
+}
+
+func (p *parser) parseMul() (node, error) {
+	return p.binaryLevel(p.parseUnary, true, "*", "/")
+}
+
+func (p *parser) parseUnary() (node, error) {
+	op, ok := p.peek("!", "-")
+	if !ok {
+		return p.parsePrimary()
+	}
+	p.pos++
+	if err := p.nest(); err != nil {
+		return nil, err
+	}
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	p.depth--
+	return p.add(unary{op: op, x: x})
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("%w: unexpected end", ErrInvalid)
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+
+	switch tok.kind {
+	case 'n':
+		v, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("%w: invalid number %q", ErrInvalid, tok.text)
+		}
+		return p.add(literal{NumberValue(v)})
+	case 's':
+		return p.add(literal{StringValue(tok.text)})
+	case 'i':
+		switch tok.text {
+		case "true", "false":
+			return p.add(literal{BoolValue(tok.text == "true")})
+		case "answered", "count":
+			if err := p.expect("("); err != nil {
+				return nil, err
+			}
+			if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != 'i' {
+				return nil, fmt.Errorf("%w: %s takes the name of a question", ErrInvalid, tok.text)
+			}
+			arg := p.tokens[p.pos].text
+			p.pos++
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			p.vars[arg] = true
+			return p.add(call{fn: tok.text, arg: arg})
+		}
+		if _, ok := p.peek("("); ok {
+			return nil, fmt.Errorf("%w: unknown function %q", ErrInvalid, tok.text)
+		}
+		p.vars[tok.text] = true
+		return p.add(variable{name: tok.text})
+	}
+
+	if tok.text != "(" {
+		return nil, fmt.Errorf("%w: unexpected %q", ErrInvalid, tok.text)
+	}
+	if err := p.nest(); err != nil {
+		return nil, err
+	}
+	x, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	p.depth--
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
@@ -0,0 +1,159 @@
+package expr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+var testTypes = map[string]Type{
+	"start":    String,
+	"end":      String,
+	"adults":   Number,
+	"children": Number,
+	"rooms":    Number,
+	"consent":  Bool,
+	"toppings": List,
+}
+
+func TestEval(t *testing.T) {
+	vars := map[string]Value{
+		"start":    StringValue("2024-05-01"),
+		"end":      StringValue("2024-05-03"),
+		"adults":   NumberValue(2),
+		"children": NumberValue(1.5),
+		"rooms":    Null(Number),
+		"toppings": ListValue([]string{"cheese", "olives"}),
+	}
+
+	for src, want := range map[string]bool{
+		`end > start`:                            true,
+		`start >= "2024-06-01"`:                  false,
+		`adults + children * 2 == 5`:             true,
+		`(adults + children) * 2 == 7`:           true,
+		`-adults < 0 && !(adults == 3)`:          true,
+		`adults / 4 == 0.5`:                      true,
+		`count(toppings) >= 2`:                   true,
+		`answered(rooms) || adults <= 4`:         true,
+		`!answered(rooms)`:                       true,
+		`answered(rooms) && rooms > adults`:      false,
+		`count(toppings) == 2 || rooms > adults`: true,
+		`"say \"hi\"" != "say hi"`:               true,
+	} {
+		e, err := Parse(src)
+		if err != nil {
+			t.Errorf("Parse(%s): %v", src, err)
+			continue
+		}
+		if err := e.Check(testTypes); err != nil {
+			t.Errorf("Check(%s): %v", src, err)
+			continue
+		}
+		if got, err := e.Eval(vars); err != nil || got != want {
+			t.Errorf("Eval(%s) = %v, %v; want %v", src, got, err, want)
+		}
+	}
+
+	for src, want := range map[string]error{
+		`rooms > adults`:  ErrUnanswered,
+		`consent`:         ErrUnanswered,
+		`adults / 0 == 1`: ErrDivisionByZero,
+	} {
+		e, err := Parse(src)
+		if err != nil {
+			t.Fatalf("Parse(%s): %v", src, err)
+		}
+		if _, err := e.Eval(vars); !errors.Is(err, want) {
+			t.Errorf("Eval(%s) error = %v, want %v", src, err, want)
+		}
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	for _, src := range []string{
+		``,
+		`adults >`,
+		`adults < children < rooms`,
+		`exec("rm -rf /")`,
+		`count(toppings[0])`,
+		`start.length > 3`,
+		`adults = 2`,
+		`"unterminated`,
+		`"\n" == start`,
+		`1.2.3 > adults`,
+		`unknown > 2`,
+		`adults + start > 2`,
+		`adults + 2`,
+		`toppings == toppings`,
+		`count(adults) > 1`,
+		`!adults`,
+		`consent < true`,
+		strings.Repeat("(", MaxDepth+1) + "consent" + strings.Repeat(")", MaxDepth+1),
+		strings.Repeat("!", MaxDepth+1) + "consent",
+		strings.Repeat("adults + ", MaxNodes/2) + "adults > 0",
+		`start == "` + strings.Repeat("x", MaxLength) + `"`,
+	} {
+		e, err := Parse(src)
+		if err == nil {
+			err = e.Check(testTypes)
+		}
+		if !errors.Is(err, ErrInvalid) {
+			t.Errorf("%.40s: error = %v, want ErrInvalid", src, err)
+		}
+	}
+}
+
+func TestVariables(t *testing.T) {
+	e, err := Parse(`answered(rooms) && adults + adults > count(toppings)`)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if got := strings.Join(e.Variables(), ","); got != "adults,rooms,toppings" {
+		t.Errorf("Variables = %s", got)
+	}
+}
+
+// FuzzEval checks that no expression, however malformed, makes parsing,
+// checking or evaluating panic, or gets past the limits
+func FuzzEval(f *testing.F) {
+	for _, seed := range []string{
+		`end > start`,
+		`adults + children * 2 == 5 || !answered(rooms)`,
+		`count(toppings) >= 2 && consent`,
+		`"a\"b" < "c\\d"`,
+		`((((-adults))))`,
+		`adults / 0 == 1`,
+		`9999999999999999999999999999 > 1e5`,
+		`)(`,
+	} {
+		f.Add(seed)
+	}
+	vars := map[string]Value{
+		"start":    StringValue("2024-05-01"),
+		"end":      Null(String),
+		"adults":   NumberValue(2),
+		"children": NumberValue(0),
+		"rooms":    Null(Number),
+		"consent":  BoolValue(true),
+		"toppings": ListValue([]string{"cheese"}),
+	}
+
+	f.Fuzz(func(t *testing.T, src string) {
+		e, err := Parse(src)
+		if err != nil {
+			if !errors.Is(err, ErrInvalid) {
+				t.Fatalf("Parse(%q) error = %v, want ErrInvalid", src, err)
+			}
+			return
+		}
+		if len(src) > MaxLength {
+			t.Fatalf("Parse accepted %d characters", len(src))
+		}
+		if err := e.Check(testTypes); err != nil {
+			return
+		}
+		if _, err := e.Eval(vars); err != nil && !errors.Is(err, ErrUnanswered) && !errors.Is(err, ErrDivisionByZero) {
+			t.Fatalf("Eval(%q) error = %v", src, err)
+		}
+	})
+}
@@ -0,0 +1,95 @@
+// Package funnel issues the view tokens of public form definitions. A view
+// token names a session of a respondent with a form: the beacons of the
+// client carry it to tell the form was viewed and started, and the session
+// is counted once per stage in the daily funnel of the form.
+package funnel
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Stages of the funnel reported by beacons. Submissions, the last stage,
+// are counted from the responses the response service publishes.
+const (
+	StageView  = "view"
+	StageStart = "start"
+)
+
+// dayLayout is the UTC day a view token was issued on
+const dayLayout = "20060102"
+
+// sessionLength is the length of the session IDs of view tokens, in hex
+const sessionLength = 32
+
+// ErrInvalidToken is returned for view tokens not issued for the form, or
+// expired
+var ErrInvalidToken = errors.New("invalid view token")
+
+// ValidStage reports whether a beacon may report the stage
+func ValidStage(stage string) bool {
+	return stage == StageView || stage == StageStart
+}
+
+// Tokens issues and verifies the view tokens of public forms
+type Tokens struct {
+	secret string
+	now    func() time.Time
+}
+
+// NewTokens creates view tokens signed with secret
+func NewTokens(secret string) *Tokens {
+	return &Tokens{secret: secret, now: time.Now}
+}
+
+// Issue returns the view token of the respondent with respondentToken for
+// the form today, UTC, empty without a respondent token or when there are
+// no tokens. A token is "<day>.<session>.<signature>": the session is
+// derived from the form, the respondent token and the day, so every load of
+// the form by the respondent that day is the same session and the
+// definition stays cacheable; the signature is the HMAC-SHA256 over
+// "<form ID>:<day>:<session>", in hex.
+func (t *Tokens) Issue(formID uuid.UUID, respondentToken string) string {
+	if t == nil || respondentToken == "" {
+		return ""
+	}
+	day := t.now().UTC().Format(dayLayout)
+	session := t.sign("session\x00" + formID.String() + "\x00" + respondentToken + "\x00" + day)[:sessionLength]
+	return day + "." + session + "." + t.sign(formID.String()+":"+day+":"+session)
+}
+
+// Verify checks that token was issued for the form today or yesterday, UTC,
+// so a form left open overnight is still counted, and returns its session
+func (t *Tokens) Verify(formID uuid.UUID, token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || len(parts[1]) != sessionLength {
+		return "", ErrInvalidToken
+	}
+	day, session, signature := parts[0], parts[1], parts[2]
+	issued, err := time.Parse(dayLayout, day)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	today := t.now().UTC().Truncate(24 * time.Hour)
+	if issued.After(today) || issued.Before(today.AddDate(0, 0, -1)) {
+		return "", ErrInvalidToken
+	}
+	expected := t.sign(formID.String() + ":" + day + ":" + session)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return "", ErrInvalidToken
+	}
+	return session, nil
+}
+
+// sign returns the HMAC-SHA256 of message, in hex
+func (t *Tokens) sign(message string) string {
+	mac := hmac.New(sha256.New, []byte(t.secret))
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,70 @@
+package funnel
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestViewTokens(t *testing.T) {
+	formID := uuid.New()
+	now := time.Date(2026, 10, 16, 23, 30, 0, 0, time.UTC)
+	tokens := NewTokens("secret")
+	tokens.now = func() time.Time { return now }
+
+	token := tokens.Issue(formID, "respondent-1")
+	if token == "" || !strings.HasPrefix(token, "20261016.") {
+		t.Fatalf("token = %q, want one of the day", token)
+	}
+	// Every load of the day is the same session, other respondents have
+	// their own
+	if again := tokens.Issue(formID, "respondent-1"); again != token {
+		t.Errorf("token changed between loads: %q, then %q", token, again)
+	}
+	if other := tokens.Issue(formID, "respondent-2"); other == token {
+		t.Error("two respondents share a token")
+	}
+	if empty := tokens.Issue(formID, ""); empty != "" {
+		t.Errorf("token without respondent = %q, want none", empty)
+	}
+	var none *Tokens
+	if empty := none.Issue(formID, "respondent-1"); empty != "" {
+		t.Errorf("token without tokens = %q, want none", empty)
+	}
+
+	session, err := tokens.Verify(formID, token)
+	if err != nil || len(session) != sessionLength {
+		t.Fatalf("Verify = %q, %v, want the session", session, err)
+	}
+
+	// Forms left open overnight are counted the day after
+	now = now.Add(time.Hour)
+	if again, err := tokens.Verify(formID, token); err != nil || again != session {
+		t.Errorf("Verify the next day = %q, %v, want the session", again, err)
+	}
+
+	tampered := strings.Replace(token, session, strings.Repeat("0", sessionLength), 1)
+	for _, tt := range []struct {
+		name   string
+		tokens *Tokens
+		formID uuid.UUID
+		token  string
+	}{
+		{"other form", tokens, uuid.New(), token},
+		{"other secret", NewTokens("other"), formID, token},
+		{"tampered", tokens, formID, tampered},
+		{"malformed", tokens, formID, "view-token"},
+	} {
+		if _, err := tt.tokens.Verify(tt.formID, tt.token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: err = %v, want ErrInvalidToken", tt.name, err)
+		}
+	}
+
+	now = now.AddDate(0, 0, 1)
+	if _, err := tokens.Verify(formID, token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expired: err = %v, want ErrInvalidToken", err)
+	}
+}
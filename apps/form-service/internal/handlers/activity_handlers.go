@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/service"
+)
+
+// ActivityHandler handles HTTP requests for the activity feed of forms
+type ActivityHandler struct {
+	activityService service.ActivityService
+}
+
+// NewActivityHandler creates a new activity handler instance
+func NewActivityHandler(activityService service.ActivityService) *ActivityHandler {
+	return &ActivityHandler{
+		activityService: activityService,
+	}
+}
+
+// ListActivity serves the activity feed of a form
+// @Summary     List form activity
+// @Description Who changed what in the definition of the form, and when, newest first. Updates name the fields that changed, never their values; responses are never part of the feed. Pass next_cursor as cursor for the following page. The feed keeps the latest activities of each form only. Builders open on the form also receive new activities as activity frames of the collaboration WebSocket. Requires the owner or a collaborator.
+// @Tags        forms
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id     path     string   true  "Form ID" format(uuid)
+// @Param       cursor query    string   false "Cursor returned as next_cursor, empty for the newest activities"
+// @Param       limit  query    int      false "Page size, 50 by default and at most 200"
+// @Param       action query    []string false "Keep these actions only, repeated or comma separated" collectionFormat(multi) Enums(form.created,form.updated,form.published,form.translation_updated,question.added,question.updated,question.deleted,questions.reordered,form.merged)
+// @Success     200    {object} service.ActivityPage
+// @Failure     400    {object} ErrorResponse
+// @Failure     401    {object} ErrorResponse
+// @Failure     403    {object} ErrorResponse
+// @Failure     404    {object} ErrorResponse
+// @Failure     500    {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/activity [get]
+func (h *ActivityHandler) ListActivity(c *gin.Context) {
+	userID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	formID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form ID"})
+		return
+	}
+
+	query := service.ActivityQuery{Cursor: c.Query("cursor")}
+	query.Limit, err = strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil || query.Limit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+	for _, values := range c.QueryArray("action") {
+		for _, value := range strings.Split(values, ",") {
+			action, err := models.ParseActivityAction(strings.TrimSpace(value))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			query.Actions = append(query.Actions, action)
+		}
+	}
+
+	page, err := h.activityService.ListActivity(c.Request.Context(), formID, userID, query)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidCursor):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case isNotFound(err):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case isAccessDenied(err):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/analytics"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/service"
+)
+
+// AnalyticsHandler handles HTTP requests for the drill-down analytics of
+// forms
+type AnalyticsHandler struct {
+	querySvc    service.AnalyticsQueryService
+	csvRowLimit int
+}
+
+// NewAnalyticsHandler creates a new analytics handler instance. Results
+// rendered as CSV have at most csvRowLimit rows.
+func NewAnalyticsHandler(querySvc service.AnalyticsQueryService, csvRowLimit int) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		querySvc:    querySvc,
+		csvRowLimit: csvRowLimit,
+	}
+}
+
+// Query handles drill-down queries over the responses of a form
+// @Summary     Query form responses
+// @Description Counts the answers to a question among the responses matching every filter, submitted in [from, to), per group. Groups are the answers to another question, the responses not answering it in the null group, or UTC time buckets. Percentages are of the responses of the group; those of a multiple choice question may add up to more than 100. At most 1000 group and answer pairs are returned, truncated is set when there were more. Filtering or grouping on a text, textarea or email question with more than ANALYTICS_QUERY_MAX_DISTINCT_VALUES distinct answers is refused with 422. Responses are read from the event store projection, so they lag submissions by the projection delay. With Accept: text/csv or format=csv the result is returned as CSV, a row per group and answer.
+// @Tags        analytics
+// @Accept      json
+// @Produce     json
+// @Produce     text/csv
+// @Security    BearerAuth
+// @Param       id      path     string          true  "Form ID" format(uuid)
+// @Param       request body     analytics.Query true  "Query"
+// @Param       format  query    string          false "csv to render the result as CSV" Enums(json,csv)
+// @Success     200     {object} analytics.QueryResult
+// @Failure     400     {object} ErrorResponse
+// @Failure     401     {object} ErrorResponse
+// @Failure     403     {object} ErrorResponse
+// @Failure     404     {object} ErrorResponse
+// @Failure     422     {object} ErrorResponse
+// @Failure     500     {object} ErrorResponse
+// @Failure     503     {object} ErrorResponse
+// @Router      /api/v1/analytics/forms/{id}/query [post]
+func (h *AnalyticsHandler) Query(c *gin.Context) {
+	userID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	formID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form ID"})
+		return
+	}
+
+	var query analytics.Query
+	if err := c.ShouldBindJSON(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.querySvc.Query(c.Request.Context(), formID, userID, query)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrFormNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case isAccessDenied(err):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, analytics.ErrInvalidQuery):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrQueryTooBroad):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case errors.Is(err, analytics.ErrNotConfigured):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "form analytics are not available"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if wantsCSV(c) {
+		respondCSV(c, "query", result.Rows(), h.csvRowLimit)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// QuestionTimings handles the timing report of a form
+// @Summary     Get question timings
+// @Description Reports, for each question of a form in form order, the median and 90th percentile of the milliseconds it held focus and the mean times respondents returned to it, over the timing beacons of the latest ANALYTICS_TIMING_SAMPLE_LIMIT submissions sending them, test submissions aside. Drop-off is read from the drafts of signed-in respondents left idle for DRAFT_ABANDON_AFTER: each stops at its last answered question, and the drop-off rate of a question is the share of the respondents reaching it, responses and abandoned drafts answering it or a later question, who stopped there. The drafts of anonymous respondents are not counted. Timings are only reported to the users who may view the form; public results never include them.
+// @Tags        analytics
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id  path     string true "Form ID" format(uuid)
+// @Success     200 {object} analytics.TimingReport
+// @Failure     400 {object} ErrorResponse
+// @Failure     401 {object} ErrorResponse
+// @Failure     403 {object} ErrorResponse
+// @Failure     404 {object} ErrorResponse
+// @Failure     500 {object} ErrorResponse
+// @Failure     503 {object} ErrorResponse
+// @Router      /api/v1/analytics/forms/{id}/timings [get]
+func (h *AnalyticsHandler) QuestionTimings(c *gin.Context) {
+	userID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	formID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form ID"})
+		return
+	}
+
+	report, err := h.querySvc.QuestionTimings(c.Request.Context(), formID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrFormNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case isAccessDenied(err):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, analytics.ErrNotConfigured):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "form analytics are not available"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
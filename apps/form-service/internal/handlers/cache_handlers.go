@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/service"
+)
+
+// CacheHandler handles the cache clears of admins, relayed by the admin API
+// of the gateway
+type CacheHandler struct {
+	caches map[string]service.CachePurger
+}
+
+// NewCacheHandler creates a new cache handler clearing caches, keyed by the
+// name they are reported under
+func NewCacheHandler(caches map[string]service.CachePurger) *CacheHandler {
+	return &CacheHandler{
+		caches: caches,
+	}
+}
+
+// PurgeCacheRequest selects the entries to purge
+type PurgeCacheRequest struct {
+	// Pattern matches the IDs of the forms whose entries are purged, where *
+	// matches any run of characters and ? any one; every entry by default
+	Pattern string `json:"pattern" example:"*"`
+}
+
+// PurgeCacheResponse reports the number of entries purged from each cache
+type PurgeCacheResponse struct {
+	Purged map[string]int `json:"purged"`
+}
+
+// PurgeCaches is called by the gateway for POST /api/v1/admin/cache/clear.
+// It is not routed to clients.
+// @Summary     Purge caches
+// @Description Drops the cached entries of the forms whose ID matches pattern from every cache of this instance, so they are read again from their source: public_results holds the answer distributions of public results. Answers the number of entries purged from each cache. Requires the internal service token. Not routed to clients.
+// @Tags        internal
+// @Accept      json
+// @Produce     json
+// @Security    ServiceToken
+// @Param       request body     PurgeCacheRequest true "Entries to purge"
+// @Success     200     {object} PurgeCacheResponse
+// @Failure     400     {object} ErrorResponse
+// @Failure     401     {object} ErrorResponse
+// @Failure     500     {object} ErrorResponse
+// @Router      /internal/cache/purge [post]
+func (h *CacheHandler) PurgeCaches(c *gin.Context) {
+	var req PurgeCacheRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Pattern == "" {
+		req.Pattern = "*"
+	}
+
+	response := PurgeCacheResponse{Purged: make(map[string]int, len(h.caches))}
+	for name, cache := range h.caches {
+		purged, err := cache.PurgeCache(c.Request.Context(), req.Pattern)
+		if errors.Is(err, service.ErrInvalidCachePattern) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		response.Purged[name] = purged
+	}
+	c.JSON(http.StatusOK, response)
+}
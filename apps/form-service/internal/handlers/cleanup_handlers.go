@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/service"
+)
+
+// CleanupHandler handles the form cleanups of admins, relayed by the admin
+// API of the gateway
+type CleanupHandler struct {
+	cleanupService service.CleanupService
+}
+
+// NewCleanupHandler creates a new cleanup handler instance
+func NewCleanupHandler(cleanupService service.CleanupService) *CleanupHandler {
+	return &CleanupHandler{
+		cleanupService: cleanupService,
+	}
+}
+
+// StartCleanup is called by the gateway for POST /api/v1/admin/forms/cleanup.
+// It is not routed to clients.
+// @Summary     Clean up abandoned forms
+// @Description Archives the forms matching the criteria: the definition of each form is exported to storage as JSON, then the form is soft deleted and form.archived is published. A cleanup selects forms last updated before inactive_before, or forms without responses, narrowed by owner, organization and status. A dry run answers 200 with the number of matching forms and a sample of their IDs; otherwise a job is queued and answered with 202. Requires the internal service token. Not routed to clients.
+// @Tags        internal
+// @Accept      json
+// @Produce     json
+// @Security    ServiceToken
+// @Param       request body     service.CleanupRequest true "Cleanup criteria"
+// @Success     200     {object} service.CleanupPreview
+// @Success     202     {object} models.CleanupJob
+// @Failure     400     {object} ErrorResponse
+// @Failure     401     {object} ErrorResponse
+// @Failure     422     {object} ErrorResponse
+// @Failure     500     {object} ErrorResponse
+// @Router      /internal/admin/forms/cleanup [post]
+func (h *CleanupHandler) StartCleanup(c *gin.Context) {
+	var req service.CleanupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.DryRun {
+		preview, err := h.cleanupService.Preview(c.Request.Context(), req.CleanupCriteria)
+		if err != nil {
+			h.handleError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, preview)
+		return
+	}
+
+	job, err := h.cleanupService.Start(c.Request.Context(), req.CleanupCriteria, req.RequestedBy)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetCleanupJob is called by the gateway for the progress of a cleanup. It
+// is not routed to clients.
+// @Summary     Get a cleanup job
+// @Description Returns the status of the cleanup job and the number of forms matched, archived and failed so far. Requires the internal service token. Not routed to clients.
+// @Tags        internal
+// @Produce     json
+// @Security    ServiceToken
+// @Param       id  path     string true "Cleanup job ID" format(uuid)
+// @Success     200 {object} models.CleanupJob
+// @Failure     400 {object} ErrorResponse
+// @Failure     401 {object} ErrorResponse
+// @Failure     404 {object} ErrorResponse
+// @Failure     500 {object} ErrorResponse
+// @Router      /internal/admin/forms/cleanup/{id} [get]
+func (h *CleanupHandler) GetCleanupJob(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cleanup job ID"})
+		return
+	}
+
+	job, err := h.cleanupService.GetJob(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// CancelCleanupJob is called by the gateway to cancel a cleanup. It is not
+// routed to clients.
+// @Summary     Cancel a cleanup job
+// @Description Cancels a queued or running cleanup job. A running job stops after the page of forms it is archiving; forms archived before stay archived. Jobs that finished answer 409. Requires the internal service token. Not routed to clients.
+// @Tags        internal
+// @Produce     json
+// @Security    ServiceToken
+// @Param       id  path     string true "Cleanup job ID" format(uuid)
+// @Success     200 {object} models.CleanupJob
+// @Failure     400 {object} ErrorResponse
+// @Failure     401 {object} ErrorResponse
+// @Failure     404 {object} ErrorResponse
+// @Failure     409 {object} ErrorResponse
+// @Failure     500 {object} ErrorResponse
+// @Router      /internal/admin/forms/cleanup/{id} [delete]
+func (h *CleanupHandler) CancelCleanupJob(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cleanup job ID"})
+		return
+	}
+
+	job, err := h.cleanupService.CancelJob(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// handleError maps cleanup service errors to HTTP responses
+func (h *CleanupHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrCleanupJobNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrCleanupInvalid):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrCleanupJobFinished):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/service"
+)
+
+// CollaboratorHandler handles HTTP requests for the collaborators of a form
+type CollaboratorHandler struct {
+	collaboratorService service.CollaboratorService
+}
+
+// NewCollaboratorHandler creates a new collaborator handler instance
+func NewCollaboratorHandler(collaboratorService service.CollaboratorService) *CollaboratorHandler {
+	return &CollaboratorHandler{
+		collaboratorService: collaboratorService,
+	}
+}
+
+// InviteCollaborator handles invitations of collaborators to a form
+// @Summary     Invite a collaborator
+// @Description Grants a user a role on the form. Viewers read the form, editors also change it and its questions, managers also publish and delete it and manage its collaborators. Requires the owner or a manager.
+// @Tags        collaborators
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id      path     string                            true "Form ID" format(uuid)
+// @Param       request body     service.InviteCollaboratorRequest true "Collaborator"
+// @Success     201     {object} models.Collaborator
+// @Failure     400     {object} ErrorResponse
+// @Failure     401     {object} ErrorResponse
+// @Failure     403     {object} ErrorResponse
+// @Failure     404     {object} ErrorResponse
+// @Failure     409     {object} ErrorResponse
+// @Failure     500     {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/collaborators [post]
+func (h *CollaboratorHandler) InviteCollaborator(c *gin.Context) {
+	userID, formID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	var req service.InviteCollaboratorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	collaborator, err := h.collaboratorService.InviteCollaborator(c.Request.Context(), formID, userID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, collaborator)
+}
+
+// ListCollaborators handles requests for the collaborators of a form
+// @Summary     List collaborators
+// @Tags        collaborators
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id  path     string true "Form ID" format(uuid)
+// @Success     200 {object} CollaboratorListResponse
+// @Failure     400 {object} ErrorResponse
+// @Failure     401 {object} ErrorResponse
+// @Failure     403 {object} ErrorResponse
+// @Failure     404 {object} ErrorResponse
+// @Failure     500 {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/collaborators [get]
+func (h *CollaboratorHandler) ListCollaborators(c *gin.Context) {
+	userID, formID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	collaborators, err := h.collaboratorService.ListCollaborators(c.Request.Context(), formID, userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, CollaboratorListResponse{Collaborators: collaborators})
+}
+
+// UpdateCollaborator handles role changes of a collaborator
+// @Summary     Change the role of a collaborator
+// @Description Requires the owner or a manager.
+// @Tags        collaborators
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id             path     string                            true "Form ID" format(uuid)
+// @Param       collaboratorId path     string                            true "Collaborator ID" format(uuid)
+// @Param       request        body     service.UpdateCollaboratorRequest true "Role"
+// @Success     200            {object} models.Collaborator
+// @Failure     400            {object} ErrorResponse
+// @Failure     401            {object} ErrorResponse
+// @Failure     403            {object} ErrorResponse
+// @Failure     404            {object} ErrorResponse
+// @Failure     500            {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/collaborators/{collaboratorId} [put]
+func (h *CollaboratorHandler) UpdateCollaborator(c *gin.Context) {
+	userID, formID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+	collaboratorID, err := uuid.Parse(c.Param("collaboratorId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid collaborator ID"})
+		return
+	}
+
+	var req service.UpdateCollaboratorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	collaborator, err := h.collaboratorService.UpdateCollaborator(c.Request.Context(), formID, collaboratorID, userID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, collaborator)
+}
+
+// RemoveCollaborator handles removal of a collaborator from a form
+// @Summary     Remove a collaborator
+// @Description Revokes the access of the collaborator. Requires the owner or a manager.
+// @Tags        collaborators
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id             path     string true "Form ID" format(uuid)
+// @Param       collaboratorId path     string true "Collaborator ID" format(uuid)
+// @Success     200            {object} MessageResponse
+// @Failure     400            {object} ErrorResponse
+// @Failure     401            {object} ErrorResponse
+// @Failure     403            {object} ErrorResponse
+// @Failure     404            {object} ErrorResponse
+// @Failure     500            {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/collaborators/{collaboratorId} [delete]
+func (h *CollaboratorHandler) RemoveCollaborator(c *gin.Context) {
+	userID, formID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+	collaboratorID, err := uuid.Parse(c.Param("collaboratorId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid collaborator ID"})
+		return
+	}
+
+	if err := h.collaboratorService.RemoveCollaborator(c.Request.Context(), formID, collaboratorID, userID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{
+		Message: "Collaborator removed successfully",
+	})
+}
+
+// parseRequest reads the caller and the form ID, writing the error response
+// when either is invalid
+func (h *CollaboratorHandler) parseRequest(c *gin.Context) (userID, formID uuid.UUID, ok bool) {
+	userID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	formID, err = uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, formID, true
+}
+
+// handleError maps collaborator service errors to HTTP responses
+func (h *CollaboratorHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrCollaboratorNotFound), isNotFound(err):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case isAccessDenied(err):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrCollaboratorExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidCollaborator):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
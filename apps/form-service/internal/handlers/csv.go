@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultCSVRowLimit caps the rows of the CSV renderings of list endpoints;
+// larger datasets go through the export API
+const DefaultCSVRowLimit = 10000
+
+const csvMediaType = "text/csv"
+
+// exportAPI is where clients asking for more rows than a CSV rendering
+// allows are pointed to
+const exportAPI = "/api/v1/forms/{id}/exports"
+
+// CSVTooLargeResponse refuses a CSV rendering of more rows than the limit
+type CSVTooLargeResponse struct {
+	Error string `json:"error" example:"25000 rows exceed the CSV limit of 10000"`
+	// Export is the asynchronous export API to use instead
+	Export string `json:"export" example:"/api/v1/forms/{id}/exports"`
+}
+
+// wantsCSV reports whether the request asks for CSV, with format=csv or an
+// Accept header preferring text/csv. JSON stays the default.
+func wantsCSV(c *gin.Context) bool {
+	if format := c.Query("format"); format != "" {
+		return strings.EqualFold(format, "csv")
+	}
+	return c.NegotiateFormat(gin.MIMEJSON, csvMediaType) == csvMediaType
+}
+
+// respondCSVTooLarge answers 413 for rows more than limit
+func respondCSVTooLarge(c *gin.Context, rows int64, limit int) {
+	c.JSON(http.StatusRequestEntityTooLarge, CSVTooLargeResponse{
+		Error:  fmt.Sprintf("%d rows exceed the CSV limit of %d", rows, limit),
+		Export: exportAPI,
+	})
+}
+
+// csvColumn is a column of the CSV rendering of a struct, named by the JSON
+// name of its field
+type csvColumn struct {
+	name  string
+	index []int
+}
+
+// csvColumns returns the columns of the rows of type t in the order of
+// their fields, fields of embedded structs included. Fields left out of
+// JSON are left out of CSV.
+func csvColumns(t reflect.Type) []csvColumn {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var columns []csvColumn
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			for _, column := range csvColumns(field.Type) {
+				column.index = append([]int{i}, column.index...)
+				columns = append(columns, column)
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		columns = append(columns, csvColumn{name: name, index: field.Index})
+	}
+	return columns
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	stringerType   = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// csvValue renders a field as a cell. Scalars are written as they are,
+// times in RFC 3339, nil as an empty cell and anything else as JSON.
+func csvValue(v reflect.Value) (string, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+
+	switch {
+	case v.Type() == timeType:
+		return v.Interface().(time.Time).Format(time.RFC3339), nil
+	case v.Type() == rawMessageType:
+		// JSON strings are unquoted, so answers read as they were given
+		var s string
+		if err := json.Unmarshal(v.Bytes(), &s); err == nil {
+			return s, nil
+		}
+		if raw := string(v.Bytes()); raw != "null" {
+			return raw, nil
+		}
+		return "", nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), nil
+	}
+	if v.Type().Implements(stringerType) && v.Kind() == reflect.Array {
+		// IDs such as uuid.UUID
+		return v.Interface().(fmt.Stringer).String(), nil
+	}
+
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return "", err
+	}
+	if string(data) == "null" {
+		return "", nil
+	}
+	return string(data), nil
+}
+
+// csvStream writes rows of one struct type as CSV to the response, header
+// first, flushing the rows to the client as they are written
+type csvStream struct {
+	c       *gin.Context
+	w       *csv.Writer
+	columns []csvColumn
+	record  []string
+}
+
+// newCSVStream starts a CSV response of rows of type row, offered for
+// download as name.csv. The envelope of JSON responses is left out.
+func newCSVStream(c *gin.Context, name string, row reflect.Type) (*csvStream, error) {
+	columns := csvColumns(row)
+	s := &csvStream{
+		c:       c,
+		w:       csv.NewWriter(c.Writer),
+		columns: columns,
+		record:  make([]string, len(columns)),
+	}
+
+	c.Header("Content-Type", csvMediaType+"; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".csv"))
+	c.Status(http.StatusOK)
+
+	for i, column := range columns {
+		s.record[i] = column.name
+	}
+	if err := s.w.Write(s.record); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Write writes a row, a struct or a pointer to one
+func (s *csvStream) Write(row interface{}) error {
+	v := reflect.Indirect(reflect.ValueOf(row))
+	for i, column := range s.columns {
+		field, err := v.FieldByIndexErr(column.index)
+		if err != nil {
+			// A field of a nil embedded struct
+			s.record[i] = ""
+			continue
+		}
+		if s.record[i], err = csvValue(field); err != nil {
+			return fmt.Errorf("failed to render %s: %w", column.name, err)
+		}
+	}
+	return s.w.Write(s.record)
+}
+
+// Flush sends the rows written so far to the client
+func (s *csvStream) Flush() error {
+	s.w.Flush()
+	if err := s.w.Error(); err != nil {
+		return err
+	}
+	s.c.Writer.Flush()
+	return nil
+}
+
+// abort ends a CSV response failing after its status was sent; the client
+// sees a truncated download
+func (s *csvStream) abort(err error) {
+	_ = s.c.Error(err)
+	s.c.Abort()
+}
+
+// respondCSV renders rows, a slice of structs, as CSV, or answers 413 when
+// there are more than limit
+func respondCSV(c *gin.Context, name string, rows interface{}, limit int) {
+	v := reflect.ValueOf(rows)
+	if v.Kind() != reflect.Slice {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot render as CSV"})
+		return
+	}
+	if v.Len() > limit {
+		respondCSVTooLarge(c, int64(v.Len()), limit)
+		return
+	}
+
+	stream, err := newCSVStream(c, name, v.Type().Elem())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for i := 0; i < v.Len(); i++ {
+		if err := stream.Write(v.Index(i).Interface()); err != nil {
+			stream.abort(err)
+			return
+		}
+	}
+	if err := stream.Flush(); err != nil {
+		stream.abort(err)
+	}
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/service"
+)
+
+type csvBase struct {
+	ID uuid.UUID `json:"id"`
+}
+
+type csvRow struct {
+	csvBase
+	Title   string          `json:"title"`
+	Note    *string         `json:"note,omitempty"`
+	Answer  json.RawMessage `json:"answer"`
+	Tags    []string        `json:"tags"`
+	At      time.Time       `json:"at"`
+	Secret  string          `json:"-"`
+	private string
+}
+
+func renderCSV(t *testing.T, query, accept string, rows interface{}, limit int) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/rows", func(c *gin.Context) {
+		if !wantsCSV(c) {
+			c.JSON(http.StatusOK, rows)
+			return
+		}
+		respondCSV(c, "rows", rows, limit)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/rows"+query, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCSVQuotesValues(t *testing.T) {
+	id := uuid.New()
+	note := "line one\nline two"
+	at := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	rows := []csvRow{
+		{csvBase: csvBase{ID: id}, Title: `Pizza, "large"`, Note: &note, Answer: json.RawMessage(`"a, b"`), Tags: []string{"x", "y"}, At: at, Secret: "hidden"},
+		{Title: "plain", Answer: json.RawMessage(`null`)},
+	}
+
+	rec := renderCSV(t, "", "text/csv", rows, 10)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/csv") {
+		t.Errorf("Content-Type = %q, want text/csv", got)
+	}
+
+	body := rec.Body.String()
+	wantFirst := id.String() + `,"Pizza, ""large""","line one` + "\n" + `line two","a, b","[""x"",""y""]",2026-10-16T09:30:00Z`
+	if !strings.Contains(body, wantFirst) {
+		t.Errorf("CSV = %q, want the first row quoted as %q", body, wantFirst)
+	}
+	if strings.Contains(body, "hidden") {
+		t.Error("CSV carries a field left out of JSON")
+	}
+
+	records, err := csv.NewReader(strings.NewReader(body)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"id", "title", "note", "answer", "tags", "at"},
+		{id.String(), `Pizza, "large"`, "line one\nline two", "a, b", `["x","y"]`, "2026-10-16T09:30:00Z"},
+		{uuid.Nil.String(), "plain", "", "", "", "0001-01-01T00:00:00Z"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("records = %q, want %q", records, want)
+	}
+}
+
+func TestCSVNegotiation(t *testing.T) {
+	rows := []csvRow{{Title: "one"}}
+	for _, tc := range []struct {
+		query, accept string
+		csv           bool
+	}{
+		{"", "", false},
+		{"", "*/*", false},
+		{"", "application/json", false},
+		{"", "text/csv", true},
+		{"", "text/csv;q=0.9, application/json;q=0.5", true},
+		{"?format=csv", "application/json", true},
+		{"?format=json", "text/csv", false},
+	} {
+		rec := renderCSV(t, tc.query, tc.accept, rows, 10)
+		isCSV := strings.HasPrefix(rec.Header().Get("Content-Type"), "text/csv")
+		if isCSV != tc.csv {
+			t.Errorf("%q with Accept %q: CSV = %v, want %v", tc.query, tc.accept, isCSV, tc.csv)
+		}
+	}
+}
+
+func TestCSVRowLimit(t *testing.T) {
+	rec := renderCSV(t, "?format=csv", "", []csvRow{{}, {}, {}}, 2)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413", rec.Code)
+	}
+	var body CSVTooLargeResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Export != exportAPI {
+		t.Errorf("body = %s, want a pointer to the export API", rec.Body.String())
+	}
+}
+
+// listedForms serves forms in pages
+type listedForms struct {
+	service.FormService
+	forms []*models.Form
+	pages int
+}
+
+func (l *listedForms) GetUserForms(_ context.Context, _ uuid.UUID, page, limit int) (*service.PaginatedFormsResponse, error) {
+	l.pages++
+	start := (page - 1) * limit
+	end := start + limit
+	if end > len(l.forms) {
+		end = len(l.forms)
+	}
+	return &service.PaginatedFormsResponse{
+		Forms:      l.forms[start:end],
+		Total:      int64(len(l.forms)),
+		Page:       page,
+		Limit:      limit,
+		TotalPages: (len(l.forms) + limit - 1) / limit,
+	}, nil
+}
+
+func TestGetUserFormsCSV(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	forms := &listedForms{}
+	for i := 0; i < 150; i++ {
+		forms.forms = append(forms.forms, &models.Form{ID: uuid.New(), Title: "Survey, part " + string(rune('A'+i%26)), Status: models.FormStatusDraft})
+	}
+
+	get := func(limit int) *httptest.ResponseRecorder {
+		router := gin.New()
+		router.Use(func(c *gin.Context) { c.Set("user_id", uuid.NewString()) })
+		router.GET("/forms", NewFormHandler(forms, nil, limit).GetUserForms)
+		req := httptest.NewRequest(http.MethodGet, "/forms?limit=10", nil)
+		req.Header.Set("Accept", "text/csv")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get(1000)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 151 || records[0][0] != "id" || records[1][4] != "Survey, part A" {
+		t.Fatalf("got %d records starting %q, want a header and every form", len(records), records[:2])
+	}
+	if forms.pages != 2 {
+		t.Errorf("fetched %d pages, want 2", forms.pages)
+	}
+
+	if rec := get(100); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status over the row limit = %d, want 413", rec.Code)
+	}
+}
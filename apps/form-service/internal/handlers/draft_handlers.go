@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/service"
+)
+
+// RespondentTokenHeader carries the client-generated token identifying an
+// anonymous respondent's draft
+const RespondentTokenHeader = "X-Respondent-Token"
+
+// draftBodyLimit bounds the request body of an autosave; the answers blob
+// itself is checked against models.MaxDraftSizeBytes
+const draftBodyLimit = models.MaxDraftSizeBytes + 1024
+
+// DraftHandler handles HTTP requests for response drafts
+type DraftHandler struct {
+	draftService service.DraftService
+}
+
+// NewDraftHandler creates a new draft handler instance
+func NewDraftHandler(draftService service.DraftService) *DraftHandler {
+	return &DraftHandler{
+		draftService: draftService,
+	}
+}
+
+// SaveDraft handles autosave of partial answers
+// @Summary     Autosave a response draft
+// @Description Drafts belong to the signed-in user, or to the X-Respondent-Token of an anonymous respondent.
+// @Tags        drafts
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id                 path     string                   true  "Form ID" format(uuid)
+// @Param       X-Respondent-Token header   string                   false "Anonymous respondent token"
+// @Param       draft              body     service.SaveDraftRequest true  "Partial answers"
+// @Success     200                {object} DraftSavedResponse
+// @Failure     400                {object} ErrorResponse
+// @Failure     413                {object} ErrorResponse
+// @Failure     422                {object} ErrorResponse
+// @Failure     500                {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/responses/draft [put]
+func (h *DraftHandler) SaveDraft(c *gin.Context) {
+	formID, ok := h.parseFormID(c)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, draftBodyLimit)
+
+	var req service.SaveDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			h.handleError(c, service.ErrDraftTooLarge)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	draft, err := h.draftService.SaveDraft(c.Request.Context(), formID, h.owner(c), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, DraftSavedResponse{
+		FormID:    draft.FormID,
+		SizeBytes: draft.SizeBytes,
+		UpdatedAt: draft.UpdatedAt,
+		ExpiresAt: draft.ExpiresAt,
+	})
+}
+
+// GetDraft handles retrieval of a draft for resume
+// @Summary     Get a response draft
+// @Tags        drafts
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id                 path     string true  "Form ID" format(uuid)
+// @Param       X-Respondent-Token header   string false "Anonymous respondent token"
+// @Success     200                {object} models.ResponseDraft
+// @Failure     400                {object} ErrorResponse
+// @Failure     404                {object} ErrorResponse
+// @Failure     500                {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/responses/draft [get]
+func (h *DraftHandler) GetDraft(c *gin.Context) {
+	formID, ok := h.parseFormID(c)
+	if !ok {
+		return
+	}
+
+	draft, err := h.draftService.GetDraft(c.Request.Context(), formID, h.owner(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, draft)
+}
+
+// ConsumeDraft is called by the response service when a response is finally
+// submitted. It is an internal endpoint, not routed by the gateway.
+// @Summary     Consume a response draft
+// @Description Returns and deletes the draft of a submitted response. Requires the internal service token. Not routed by the gateway.
+// @Tags        internal
+// @Accept      json
+// @Produce     json
+// @Security    ServiceToken
+// @Param       id    path     string                      true "Form ID" format(uuid)
+// @Param       owner body     service.ConsumeDraftRequest true "Draft owner"
+// @Success     200   {object} models.ResponseDraft
+// @Failure     400   {object} ErrorResponse
+// @Failure     401   {object} ErrorResponse
+// @Failure     404   {object} ErrorResponse
+// @Failure     500   {object} ErrorResponse
+// @Router      /internal/forms/{id}/responses/draft/consume [post]
+func (h *DraftHandler) ConsumeDraft(c *gin.Context) {
+	formID, ok := h.parseFormID(c)
+	if !ok {
+		return
+	}
+
+	var req service.ConsumeDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	owner := models.DraftOwner{UserID: req.UserID, RespondentToken: req.RespondentToken}
+	draft, err := h.draftService.ConsumeDraft(c.Request.Context(), formID, owner)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, draft)
+}
+
+// owner identifies the draft owner from the authenticated user or the
+// respondent token header
+func (h *DraftHandler) owner(c *gin.Context) models.DraftOwner {
+	return models.DraftOwner{
+		UserID:          middleware.GetUserID(c),
+		RespondentToken: c.GetHeader(RespondentTokenHeader),
+	}
+}
+
+// parseFormID extracts the form ID from the route
+func (h *DraftHandler) parseFormID(c *gin.Context) (uuid.UUID, bool) {
+	formID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form ID"})
+		return uuid.Nil, false
+	}
+	return formID, true
+}
+
+// handleError maps draft service errors to HTTP responses
+func (h *DraftHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrDraftNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrDraftTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrDraftRejected):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
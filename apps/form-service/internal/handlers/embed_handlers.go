@@ -0,0 +1,322 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"text/template"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/funnel"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/service"
+)
+
+// EmbedHandler serves published forms to the sites they are embedded on
+type EmbedHandler struct {
+	formService service.FormService
+	viewTokens  *funnel.Tokens
+	apiBaseURL  string
+}
+
+// NewEmbedHandler creates a new embed handler. Definitions carry view
+// tokens issued by viewTokens, none when nil; apiBaseURL is the public URL
+// embeds reach the API at, the API Gateway.
+func NewEmbedHandler(formService service.FormService, viewTokens *funnel.Tokens, apiBaseURL string) *EmbedHandler {
+	return &EmbedHandler{
+		formService: formService,
+		viewTokens:  viewTokens,
+		apiBaseURL:  strings.TrimSuffix(apiBaseURL, "/"),
+	}
+}
+
+// GetEmbedScript serves the loader sites include to embed a form
+// @Summary     Get the embed loader of a form
+// @Description Public. Include it as <script src=".../embed.js" data-target="#container"></script>; without data-target the form renders after the script tag. The loader fetches the definition and submits responses from the embedding site, which must be allow-listed in embed_allowed_origins. It renders the CAPTCHA widget or honeypot field of forms with spam protection, and sends the funnel beacons of the form as it is viewed and started; add data-test="true" to the script tag to leave a test page out of the funnel and the responses counted.
+// @Tags        embed
+// @Produce     application/javascript
+// @Param       id  path     string true "Form ID" format(uuid)
+// @Success     200 {string} string
+// @Failure     400 {object} ErrorResponse
+// @Failure     404 {object} ErrorResponse
+// @Failure     500 {object} ErrorResponse
+// @Router      /api/v1/public/forms/{id}/embed.js [get]
+func (h *EmbedHandler) GetEmbedScript(c *gin.Context) {
+	formID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form ID"})
+		return
+	}
+	if _, err := h.formService.GetPublishedForm(c.Request.Context(), formID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	var script bytes.Buffer
+	err = embedLoader.Execute(&script, map[string]string{
+		"FormID":  formID.String(),
+		"APIBase": h.apiBaseURL,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.Data(http.StatusOK, "application/javascript; charset=utf-8", script.Bytes())
+}
+
+// GetEmbedDefinition serves a published form to the sites embedding it
+// @Summary     Get the embed definition of a form
+// @Description Public. Browsers are only let read it from the origins in the embed_allowed_origins setting of the form; other origins get 403. embed.frame_ancestors is the Content-Security-Policy directive to serve pages framing the form with. spam_protection is the challenge submissions must pass, if any. shuffle_seed, set when a question randomizes its options and the respondent token is sent, seeds the shuffle of those options; it is the same on every load for the respondent. view_token, set when the respondent token is sent, is carried by the funnel beacons of the respondent; it changes daily. Definitions carry an ETag, except those with honeypot protection; send it back in If-None-Match to get 304 without the body while the form is unchanged.
+// @Tags        embed
+// @Produce     json
+// @Param       id  path     string true "Form ID" format(uuid)
+// @Param       X-Respondent-Token header string false "Anonymous respondent token the shuffle seed and view token are derived from"
+// @Param       If-None-Match      header string false "ETag of the copy the client holds"
+// @Success     200 {object} EmbedDefinitionResponse
+// @Success     304
+// @Failure     400 {object} ErrorResponse
+// @Failure     403 {object} ErrorResponse
+// @Failure     404 {object} ErrorResponse
+// @Failure     500 {object} ErrorResponse
+// @Router      /api/v1/public/forms/{id}/definition [get]
+func (h *EmbedHandler) GetEmbedDefinition(c *gin.Context) {
+	formID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form ID"})
+		return
+	}
+
+	published, err := h.formService.GetPublishedForm(c.Request.Context(), formID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	// The service-wide CORS headers allow any origin; embeds are only let in
+	// from the allow-list, without credentials
+	header := c.Writer.Header()
+	header.Del("Access-Control-Allow-Origin")
+	header.Del("Access-Control-Allow-Credentials")
+	header.Add("Vary", "Origin")
+	header.Add("Vary", RespondentTokenHeader)
+	if origin := c.GetHeader("Origin"); origin != "" {
+		if !published.Settings.EmbedAllowed(origin) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "this site is not allowed to embed the form"})
+			return
+		}
+		header.Set("Access-Control-Allow-Origin", origin)
+	}
+
+	respondent := c.GetHeader(RespondentTokenHeader)
+	seed := published.RespondentShuffleSeed(respondent)
+	viewToken := h.viewTokens.Issue(formID, respondent)
+	respondWithDefinition(c, published, EmbedDefinitionResponse{
+		Form:           published.Form,
+		Questions:      published.Questions,
+		ResponsePolicy: published.ResponsePolicy,
+		SpamProtection: published.SpamProtection,
+		ShuffleSeed:    seed,
+		ViewToken:      viewToken,
+		Embed: EmbedPolicy{
+			AllowedOrigins: published.Settings.EmbedAllowedOrigins,
+			FrameAncestors: published.Settings.FrameAncestors(),
+			SubmitURL:      h.apiBaseURL + "/responses",
+		},
+	}, h.apiBaseURL, shuffleVariant(seed), viewToken)
+}
+
+// handleError maps service errors to HTTP responses
+func (h *EmbedHandler) handleError(c *gin.Context, err error) {
+	if isNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// embedLoader renders a published form into the embedding page and submits
+// its responses to the response service
+var embedLoader = template.Must(template.New("embed.js").Parse(`// X-Form embed loader
+(function () {
+  "use strict";
+  var formId = "{{js .FormID}}";
+  var apiBase = "{{js .APIBase}}";
+  var script = document.currentScript;
+  var selector = script && script.getAttribute("data-target");
+  // Test pages are left out of the funnel and the responses counted
+  var test = !!script && script.getAttribute("data-test") === "true";
+  var target = selector ? document.querySelector(selector) : null;
+  if (!target) {
+    target = document.createElement("div");
+    script.parentNode.insertBefore(target, script.nextSibling);
+  }
+
+  function el(tag, text) {
+    var node = document.createElement(tag);
+    if (text) {
+      node.textContent = text;
+    }
+    return node;
+  }
+
+  var inputTypes = { text: "text", number: "number", email: "email" };
+
+  // respondentToken identifies the respondent across loads of the form, for
+  // the shuffle seed and the funnel session; empty without local storage
+  function respondentToken() {
+    var key = "xform-respondent";
+    try {
+      var token = window.localStorage.getItem(key);
+      if (!token) {
+        token = window.crypto && window.crypto.randomUUID ? window.crypto.randomUUID() : Date.now().toString(36) + Math.random().toString(36).slice(2);
+        window.localStorage.setItem(key, token);
+      }
+      return token;
+    } catch (err) {
+      return "";
+    }
+  }
+
+  // beacon tells the funnel of the form the respondent reached a stage. It
+  // never holds the page up, and is dropped when it can't be sent.
+  function beacon(viewToken, stage) {
+    if (!viewToken || test) {
+      return;
+    }
+    var url = apiBase + "/public/forms/" + formId + "/beacon";
+    var body = JSON.stringify({ view_token: viewToken, stage: stage });
+    if (navigator.sendBeacon && navigator.sendBeacon(url, body)) {
+      return;
+    }
+    fetch(url, { method: "POST", body: body, credentials: "omit", keepalive: true }).catch(function () {});
+  }
+
+  var captchaProviders = {
+    recaptcha: { src: "https://www.google.com/recaptcha/api.js?render=explicit", global: "grecaptcha" },
+    hcaptcha: { src: "https://js.hcaptcha.com/1/api.js?render=explicit", global: "hcaptcha" },
+    turnstile: { src: "https://challenges.cloudflare.com/turnstile/v0/api.js?render=explicit", global: "turnstile" }
+  };
+
+  // renderCaptcha loads the widget of the CAPTCHA provider into container
+  function renderCaptcha(challenge, container) {
+    var widget = { api: null, id: null };
+    var provider = captchaProviders[challenge.provider];
+    if (!provider) {
+      return widget;
+    }
+    var slot = el("div");
+    container.appendChild(slot);
+    var callback = "xformCaptcha" + formId.replace(/-/g, "");
+    window[callback] = function () {
+      widget.api = window[provider.global];
+      widget.id = widget.api.render(slot, { sitekey: challenge.site_key });
+    };
+    var tag = el("script");
+    tag.src = provider.src + "&onload=" + callback;
+    tag.async = true;
+    document.head.appendChild(tag);
+    return widget;
+  }
+
+  var respondent = respondentToken();
+  fetch(apiBase + "/public/forms/" + formId + "/definition", {
+    headers: respondent ? { "X-Respondent-Token": respondent } : {},
+    credentials: "omit"
+  })
+    .then(function (res) {
+      if (!res.ok) {
+        throw new Error("form unavailable (" + res.status + ")");
+      }
+      return res.json();
+    })
+    .then(function (definition) {
+      var questions = definition.questions || [];
+      var settings = definition.form.settings || {};
+      var form = el("form");
+      form.className = "xform-embed";
+      form.appendChild(el("h2", definition.form.title));
+      if (definition.form.description) {
+        form.appendChild(el("p", definition.form.description));
+      }
+      questions.forEach(function (question) {
+        var label = el("label", question.title);
+        var input = el(question.type === "textarea" ? "textarea" : "input");
+        if (input.tagName === "INPUT") {
+          input.type = inputTypes[question.type] || "text";
+        }
+        input.name = question.id;
+        label.appendChild(input);
+        form.appendChild(label);
+      });
+      // Bots fill the honeypot field in; people never see it
+      var challenge = definition.spam_protection || {};
+      var honeypot = null;
+      var captcha = null;
+      if (challenge.mode === "honeypot") {
+        honeypot = el("input");
+        honeypot.name = challenge.honeypot_field;
+        honeypot.tabIndex = -1;
+        honeypot.autocomplete = "off";
+        honeypot.setAttribute("aria-hidden", "true");
+        honeypot.style.position = "absolute";
+        honeypot.style.left = "-10000px";
+        form.appendChild(honeypot);
+      } else if (challenge.mode === "captcha") {
+        captcha = renderCaptcha(challenge, form);
+      }
+      var submit = el("button", "Submit");
+      submit.type = "submit";
+      var status = el("p");
+      form.appendChild(submit);
+      form.appendChild(status);
+
+      form.addEventListener("submit", function (event) {
+        event.preventDefault();
+        var responses = questions.map(function (question) {
+          return { questionId: question.id, value: form.elements[question.id].value };
+        });
+        fetch(definition.embed.submit_url, {
+          method: "POST",
+          headers: { "Content-Type": "application/json" },
+          credentials: "omit",
+          body: JSON.stringify({
+            formId: formId,
+            responses: responses,
+            captchaToken: captcha && captcha.api ? captcha.api.getResponse(captcha.id) : undefined,
+            honeypot: honeypot ? honeypot.value : undefined,
+            challengeToken: challenge.token,
+            test: test || undefined
+          })
+        }).then(function (res) {
+          if (captcha && captcha.api) {
+            captcha.api.reset(captcha.id);
+          }
+          if (res.ok) {
+            form.reset();
+            status.textContent = settings.confirmation_message || "Thank you for your response.";
+          } else {
+            status.textContent = "Your response could not be submitted (" + res.status + ").";
+          }
+        });
+      });
+      // The first answer starts the form
+      var started = false;
+      function start() {
+        if (!started) {
+          started = true;
+          beacon(definition.view_token, "start");
+        }
+      }
+      form.addEventListener("input", start);
+      form.addEventListener("change", start);
+      target.appendChild(form);
+      beacon(definition.view_token, "view");
+    })
+    .catch(function (err) {
+      target.appendChild(el("p", "This form could not be loaded: " + err.message));
+    });
+})();
+`))
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/funnel"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/service"
+)
+
+// publishedForms serves one published form
+type publishedForms struct {
+	service.FormService
+	form *service.PublishedForm
+}
+
+func (s publishedForms) GetPublishedForm(_ context.Context, id uuid.UUID) (*service.PublishedForm, error) {
+	if id != s.form.Form.ID {
+		return nil, service.ErrFormNotFound
+	}
+	return s.form, nil
+}
+
+func TestEmbedDefinitionOrigins(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	settings := models.FormSettings{EmbedAllowedOrigins: []string{"https://www.acme.com"}}
+	form := &models.Form{ID: uuid.New(), Title: "Feedback", Status: models.FormStatusPublished}
+	handler := NewEmbedHandler(publishedForms{form: &service.PublishedForm{Form: form, Settings: settings}}, nil, "https://api.example.com/")
+
+	router := gin.New()
+	router.Use(middleware.CORS())
+	router.GET("/forms/:id/definition", handler.GetEmbedDefinition)
+	router.GET("/forms/:id/embed.js", handler.GetEmbedScript)
+
+	tests := []struct {
+		name       string
+		origin     string
+		wantStatus int
+		wantCORS   string
+	}{
+		{"allowed origin", "https://www.acme.com", http.StatusOK, "https://www.acme.com"},
+		{"allowed origin in another case", "https://WWW.acme.com", http.StatusOK, "https://WWW.acme.com"},
+		{"other origin", "https://evil.example", http.StatusForbidden, ""},
+		{"other scheme", "http://www.acme.com", http.StatusForbidden, ""},
+		{"no origin", "", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/forms/"+form.ID.String()+"/definition", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantCORS {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantCORS)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+				t.Errorf("Access-Control-Allow-Credentials = %q, want none", got)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/forms/"+form.ID.String()+"/definition", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `"frame_ancestors":"frame-ancestors 'self' https://www.acme.com"`) ||
+		!strings.Contains(body, `"submit_url":"https://api.example.com/responses"`) {
+		t.Errorf("definition = %s, want the frame ancestors and submit URL", body)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/forms/"+form.ID.String()+"/embed.js", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `var formId = "`+form.ID.String()+`"`) {
+		t.Errorf("embed.js = %d %s, want the loader of the form", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/forms/"+uuid.NewString()+"/definition", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unpublished form: status = %d, want 404", rec.Code)
+	}
+}
+
+func TestEmbedDefinitionRespondent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	form := &models.Form{ID: uuid.New(), Title: "Plans", Status: models.FormStatusPublished}
+	question := &models.Question{ID: uuid.New(), FormID: form.ID, Type: models.QuestionTypeRadio, Title: "Plan",
+		Display: []byte(`{"randomize_options":true,"pin_last_option":true}`)}
+	handler := NewEmbedHandler(publishedForms{form: &service.PublishedForm{Form: form, Questions: []*models.Question{question}}}, funnel.NewTokens("secret"), "https://api.example.com")
+
+	router := gin.New()
+	router.GET("/forms/:id/definition", handler.GetEmbedDefinition)
+
+	definition := func(token string) EmbedDefinitionResponse {
+		req := httptest.NewRequest(http.MethodGet, "/forms/"+form.ID.String()+"/definition", nil)
+		if token != "" {
+			req.Header.Set(RespondentTokenHeader, token)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if !strings.Contains(strings.Join(rec.Header().Values("Vary"), ","), RespondentTokenHeader) {
+			t.Errorf("Vary = %v, want %s", rec.Header().Values("Vary"), RespondentTokenHeader)
+		}
+		var response EmbedDefinitionResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("definition: %v", err)
+		}
+		return response
+	}
+
+	first, refreshed, other := definition("respondent-1"), definition("respondent-1"), definition("respondent-2")
+	if first.ShuffleSeed == nil || refreshed.ShuffleSeed == nil || *first.ShuffleSeed != *refreshed.ShuffleSeed {
+		t.Fatalf("seeds = %v and %v, want the same seed on a refresh", first.ShuffleSeed, refreshed.ShuffleSeed)
+	}
+	if other.ShuffleSeed == nil || *other.ShuffleSeed == *first.ShuffleSeed {
+		t.Errorf("another respondent got seed %v, want their own", other.ShuffleSeed)
+	}
+	if first.ViewToken == "" || refreshed.ViewToken != first.ViewToken || other.ViewToken == first.ViewToken {
+		t.Errorf("view tokens = %q, %q on a refresh and %q for another respondent, want one per respondent", first.ViewToken, refreshed.ViewToken, other.ViewToken)
+	}
+	if anonymous := definition(""); anonymous.ShuffleSeed != nil || anonymous.ViewToken != "" {
+		t.Errorf("without a respondent token: seed = %v, view token = %q, want none", anonymous.ShuffleSeed, anonymous.ViewToken)
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/service"
+)
+
+// respondWithETag writes body as JSON with a strong ETag hashing the
+// representation, so any change to what is returned, a publish included,
+// changes it. A request whose If-None-Match holds the ETag is answered 304
+// without the body.
+func respondWithETag(c *gin.Context, body interface{}) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	c.Header("ETag", etag)
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
+
+// etagMatches reports whether the If-None-Match header lists etag. The
+// comparison is weak, as RFC 9110 requires for If-None-Match.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// respondWithDefinition writes a response built from the definition of a
+// published form as JSON with the ETag computed when the definition was
+// read, so the body isn't hashed on every fetch. The ETag is varied by the
+// parts of the response not in the definition, such as the shuffle seed of
+// the respondent. Responses carrying a honeypot token get none, the token
+// being issued for the fetch.
+func respondWithDefinition(c *gin.Context, published *service.PublishedForm, body interface{}, variants ...string) {
+	if published.ETag == "" || (published.SpamProtection != nil && published.SpamProtection.Token != "") {
+		c.JSON(http.StatusOK, body)
+		return
+	}
+
+	etag := published.ETag
+	if len(variants) > 0 {
+		sum := sha256.Sum256([]byte(etag + "\x00" + strings.Join(variants, "\x00")))
+		etag = `"` + hex.EncodeToString(sum[:16]) + `"`
+	}
+	c.Header("ETag", etag)
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// shuffleVariant is the ETag variant of a shuffle seed, empty without one
+func shuffleVariant(seed *uint32) string {
+	if seed == nil {
+		return ""
+	}
+	return strconv.FormatUint(uint64(*seed), 10)
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/service"
+)
+
+// storedForm serves one form, as the repository holds it
+type storedForm struct {
+	service.FormService
+	form *models.Form
+}
+
+func (s storedForm) GetForm(_ context.Context, id, _ uuid.UUID) (*models.Form, error) {
+	if id != s.form.ID {
+		return nil, service.ErrFormNotFound
+	}
+	form := *s.form
+	return &form, nil
+}
+
+func TestGetFormETag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	form := &models.Form{ID: uuid.New(), Title: "Feedback", Status: models.FormStatusDraft, UpdatedAt: time.Now()}
+	handler := NewFormHandler(storedForm{form: form}, nil, DefaultCSVRowLimit)
+
+	userID := uuid.NewString()
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("user_id", userID) })
+	router.GET("/forms/:id", handler.GetForm)
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/forms/"+form.ID.String(), nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := get("")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || len(etag) < 3 || etag[0] != '"' || first.Body.Len() == 0 {
+		t.Fatalf("GET = %d with ETag %q, want 200 with a strong ETag and the form", first.Code, etag)
+	}
+
+	for _, ifNoneMatch := range []string{etag, `"other", ` + etag, "W/" + etag, "*"} {
+		rec := get(ifNoneMatch)
+		if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+			t.Errorf("GET with If-None-Match %s = %d with %d bytes, want 304 without a body", ifNoneMatch, rec.Code, rec.Body.Len())
+		}
+		if got := rec.Header().Get("ETag"); got != etag {
+			t.Errorf("304 ETag = %q, want %q", got, etag)
+		}
+	}
+	if rec := get(`"other"`); rec.Code != http.StatusOK {
+		t.Errorf("GET with a stale ETag = %d, want 200", rec.Code)
+	}
+
+	// Publishing changes the form, so the copy the client holds is stale
+	form.Status = models.FormStatusPublished
+	form.UpdatedAt = form.UpdatedAt.Add(time.Second)
+	rec := get(etag)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET after publishing = %d, want 200", rec.Code)
+	}
+	if got := rec.Header().Get("ETag"); got == etag || got == "" {
+		t.Errorf("ETag after publishing = %q, want a new ETag", got)
+	}
+}
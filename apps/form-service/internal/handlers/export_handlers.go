@@ -0,0 +1,330 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/analytics"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/download"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/service"
+)
+
+// downloadRedemptionCookie holds the redemption of a single-use export link,
+// which resumes its download
+const downloadRedemptionCookie = "export_download"
+
+// ExportHandler handles HTTP requests for the response exports of forms
+type ExportHandler struct {
+	exportService service.ExportService
+	// streaming serves exports through the service rather than signing
+	// download links
+	streaming bool
+}
+
+// NewExportHandler creates a new export handler instance. With streaming,
+// the download endpoint streams exports to authenticated callers instead of
+// signing links to them.
+func NewExportHandler(exportService service.ExportService, streaming bool) *ExportHandler {
+	return &ExportHandler{
+		exportService: exportService,
+		streaming:     streaming,
+	}
+}
+
+// StartExport queues the export of the responses of a form
+// @Summary     Export responses
+// @Description Queues the export of the responses of the form as CSV or XLSX, a row for each response. Exports are scheduled fairly across organizations, a few of each running at a time; low priority exports only run when no normal export can run instead. With include_files, the export is a ZIP archive of the responses file and a folder for each response ID holding the files answering its file questions, named after the key of their question (q and its position) and their file name. Files missing from storage or quarantined by the antivirus scan are replaced by a text file giving the reason. Exports growing past the maximum size fail. Poll the job for its progress and download link. Requires the owner or a collaborator.
+// @Tags        forms
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id      path     string                true "Form ID" format(uuid)
+// @Param       request body     service.ExportRequest true "Export options"
+// @Success     202     {object} models.ExportJob
+// @Failure     400     {object} ErrorResponse
+// @Failure     401     {object} ErrorResponse
+// @Failure     403     {object} ErrorResponse
+// @Failure     404     {object} ErrorResponse
+// @Failure     500     {object} ErrorResponse
+// @Failure     503     {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/exports [post]
+func (h *ExportHandler) StartExport(c *gin.Context) {
+	userID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	formID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form ID"})
+		return
+	}
+
+	var req service.ExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := h.exportService.Start(c.Request.Context(), formID, userID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetExport returns an export of a form
+// @Summary     Get a response export
+// @Description Returns the status of the export and its progress. Queued exports give their place among the exports of the organization waiting to run in queue_position. Running exports give the responses exported, the bytes written, the files archived and those replaced by a placeholder. Completed exports come with the download_url signing links to download them. Failed exports give the reason in error. Requires the owner or a collaborator.
+// @Tags        forms
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id    path     string true "Form ID" format(uuid)
+// @Param       jobId path     string true "Export job ID" format(uuid)
+// @Success     200   {object} models.ExportJob
+// @Failure     400   {object} ErrorResponse
+// @Failure     401   {object} ErrorResponse
+// @Failure     403   {object} ErrorResponse
+// @Failure     404   {object} ErrorResponse
+// @Failure     500   {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/exports/{jobId} [get]
+func (h *ExportHandler) GetExport(c *gin.Context) {
+	userID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	formID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form ID"})
+		return
+	}
+	jobID, err := uuid.Parse(c.Param("jobId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid export job ID"})
+		return
+	}
+
+	job, err := h.exportService.GetJob(c.Request.Context(), formID, jobID, userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// CancelExport cancels an export of a form
+// @Summary     Cancel a response export
+// @Description Cancels a queued or running export. A running export stops at its next batch of responses and what it wrote is deleted. Requires whoever started the export, or the owner or an editor.
+// @Tags        forms
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id    path     string true "Form ID" format(uuid)
+// @Param       jobId path     string true "Export job ID" format(uuid)
+// @Success     200   {object} models.ExportJob
+// @Failure     400   {object} ErrorResponse
+// @Failure     401   {object} ErrorResponse
+// @Failure     403   {object} ErrorResponse
+// @Failure     404   {object} ErrorResponse
+// @Failure     409   {object} ErrorResponse
+// @Failure     500   {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/exports/{jobId} [delete]
+func (h *ExportHandler) CancelExport(c *gin.Context) {
+	userID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	formID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form ID"})
+		return
+	}
+	jobID, err := uuid.Parse(c.Param("jobId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid export job ID"})
+		return
+	}
+
+	job, err := h.exportService.Cancel(c.Request.Context(), formID, jobID, userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// DownloadExport signs a link to download a completed export of a form
+// @Summary     Download a response export
+// @Description Returns a short-lived link downloading the completed export without authentication, Range requests included. The link points at the object storage, or at the public download endpoint when links are single-use, and stops working once expired. Deployments streaming exports through the service return the export itself instead. Requires the owner or a collaborator.
+// @Tags        forms
+// @Produce     json
+// @Produce     octet-stream
+// @Security    BearerAuth
+// @Param       id    path     string true "Form ID" format(uuid)
+// @Param       jobId path     string true "Export job ID" format(uuid)
+// @Success     200   {object} service.ExportDownload
+// @Failure     400   {object} ErrorResponse
+// @Failure     401   {object} ErrorResponse
+// @Failure     403   {object} ErrorResponse
+// @Failure     404   {object} ErrorResponse
+// @Failure     409   {object} ErrorResponse
+// @Failure     500   {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/exports/{jobId}/download [get]
+func (h *ExportHandler) DownloadExport(c *gin.Context) {
+	userID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	formID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form ID"})
+		return
+	}
+	jobID, err := uuid.Parse(c.Param("jobId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid export job ID"})
+		return
+	}
+
+	if h.streaming {
+		artifact, err := h.exportService.OpenDownload(c.Request.Context(), formID, jobID, userID)
+		if err != nil {
+			h.handleError(c, err)
+			return
+		}
+		serveExport(c, artifact)
+		return
+	}
+	link, err := h.exportService.DownloadLink(c.Request.Context(), formID, jobID, userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, link)
+}
+
+// DownloadSignedExport downloads an export with a single-use link
+// @Summary     Download a response export with a signed link
+// @Description Downloads the export a single-use link was signed for, without authentication. Range requests are served when storage allows it; otherwise the response redirects to the object storage. The link works once. Its first response sets the export_download cookie, with which Range requests past the first byte resume the download until the link expires. The link is refused once used, once expired, and once the export is cancelled.
+// @Tags        public
+// @Produce     octet-stream
+// @Param       id    path  string true "Form ID" format(uuid)
+// @Param       jobId path  string true "Export job ID" format(uuid)
+// @Param       token query string true "Download token"
+// @Success     200
+// @Success     206
+// @Success     302
+// @Failure     400   {object} ErrorResponse
+// @Failure     403   {object} ErrorResponse
+// @Failure     410   {object} ErrorResponse
+// @Failure     500   {object} ErrorResponse
+// @Router      /api/v1/public/forms/{id}/exports/{jobId}/download [get]
+func (h *ExportHandler) DownloadSignedExport(c *gin.Context) {
+	formID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form ID"})
+		return
+	}
+	jobID, err := uuid.Parse(c.Param("jobId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid export job ID"})
+		return
+	}
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+		return
+	}
+
+	resume := service.DownloadResume{Offset: rangeStart(c.GetHeader("Range"))}
+	if cookie, err := c.Request.Cookie(downloadRedemptionCookie); err == nil {
+		resume.Redemption = cookie.Value
+	}
+	artifact, err := h.exportService.RedeemDownload(c.Request.Context(), formID, jobID, token, resume)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	if artifact.Redemption != "" {
+		http.SetCookie(c.Writer, &http.Cookie{
+			Name:     downloadRedemptionCookie,
+			Value:    artifact.Redemption,
+			Path:     c.Request.URL.Path,
+			Expires:  artifact.ExpiresAt,
+			HttpOnly: true,
+			Secure:   c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https",
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+	serveExport(c, artifact)
+}
+
+// rangeStart returns the first byte a Range header asks for, 0 without
+// one or when it asks for the end of the export
+func rangeStart(header string) int64 {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok {
+		return 0
+	}
+	first, _, _ := strings.Cut(spec, ",")
+	start, _, _ := strings.Cut(strings.TrimSpace(first), "-")
+	offset, err := strconv.ParseInt(start, 10, 64)
+	if err != nil || offset < 0 {
+		return 0
+	}
+	return offset
+}
+
+// serveExport writes an export as an attachment, answering Range requests
+// when it is seekable
+func serveExport(c *gin.Context, artifact *service.ExportArtifact) {
+	if artifact.RedirectURL != "" {
+		c.Redirect(http.StatusFound, artifact.RedirectURL)
+		return
+	}
+	defer artifact.Body.Close()
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", artifact.Name))
+	c.Header("Content-Type", artifact.ContentType)
+	c.Header("Cache-Control", "private, no-store")
+	if body, ok := artifact.Body.(io.ReadSeeker); ok {
+		http.ServeContent(c.Writer, c.Request, artifact.Name, time.Time{}, body)
+		return
+	}
+	c.Status(http.StatusOK)
+	io.Copy(c.Writer, artifact.Body)
+}
+
+func (h *ExportHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrExportInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case isNotFound(err), errors.Is(err, repository.ErrExportJobNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case isAccessDenied(err):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrExportJobFinished), errors.Is(err, service.ErrExportNotReady):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, download.ErrInvalidToken):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrDownloadLinkUsed):
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	case errors.Is(err, analytics.ErrNotConfigured):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "response exports are not available"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/download"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/service"
+)
+
+// seekableExport is an export read from a seekable body
+type seekableExport struct {
+	*strings.Reader
+}
+
+func (seekableExport) Close() error { return nil }
+
+// signedExports redeems the tokens "local" and "s3" once each, and serves
+// the requests resuming the download of their redemption
+type signedExports struct {
+	service.ExportService
+	redemptions map[string]string
+}
+
+func (s *signedExports) RedeemDownload(_ context.Context, _, _ uuid.UUID, token string, resume service.DownloadResume) (*service.ExportArtifact, error) {
+	if token != "local" && token != "s3" {
+		return nil, download.ErrInvalidToken
+	}
+	redemption := ""
+	if resume.Redemption != "" && resume.Offset > 0 {
+		if s.redemptions[token] != resume.Redemption {
+			return nil, service.ErrDownloadLinkUsed
+		}
+	} else {
+		if s.redemptions[token] != "" {
+			return nil, service.ErrDownloadLinkUsed
+		}
+		redemption = "redemption-" + token
+		s.redemptions[token] = redemption
+	}
+	if token == "s3" {
+		return &service.ExportArtifact{RedirectURL: "https://exports.s3.amazonaws.com/export.csv?X-Amz-Signature=abc", Redemption: redemption}, nil
+	}
+	return &service.ExportArtifact{
+		Name:        "export-1.csv",
+		ContentType: "text/csv",
+		Body:        seekableExport{strings.NewReader("response_id,Name\nresp-000,Ada\n")},
+		Redemption:  redemption,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}, nil
+}
+
+func TestDownloadSignedExport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewExportHandler(&signedExports{redemptions: map[string]string{}}, false)
+	router := gin.New()
+	router.GET("/public/forms/:id/exports/:jobId/download", handler.DownloadSignedExport)
+	path := "/public/forms/" + uuid.NewString() + "/exports/" + uuid.NewString() + "/download"
+	get := func(token, byteRange, redemption string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path+"?token="+token, nil)
+		if byteRange != "" {
+			req.Header.Set("Range", byteRange)
+		}
+		if redemption != "" {
+			req.AddCookie(&http.Cookie{Name: downloadRedemptionCookie, Value: redemption})
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("local", "bytes=0-10", "")
+	if rec.Code != http.StatusPartialContent || rec.Body.String() != "response_id" {
+		t.Errorf("range request = %d %q, want 206 with the first 11 bytes", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Range"); got != "bytes 0-10/30" {
+		t.Errorf("Content-Range = %q", got)
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != `attachment; filename="export-1.csv"` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != downloadRedemptionCookie || cookies[0].Path != path || !cookies[0].HttpOnly {
+		t.Fatalf("cookies = %v, want the redemption scoped to the download", cookies)
+	}
+	redemption := cookies[0].Value
+
+	// The client that redeemed the link resumes where it stopped
+	rec = get("local", "bytes=11-", redemption)
+	if rec.Code != http.StatusPartialContent || rec.Body.String() != ",Name\nresp-000,Ada\n" {
+		t.Errorf("resumed download = %d %q, want 206 with the rest of the export", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Range"); got != "bytes 11-29/30" {
+		t.Errorf("resumed Content-Range = %q", got)
+	}
+
+	tests := []struct {
+		name, byteRange, redemption string
+	}{
+		{"second download", "", redemption},
+		{"bytes=0- after the redemption", "bytes=0-", redemption},
+		{"resumed without the redemption", "bytes=11-", ""},
+		{"resumed by another client", "bytes=11-", "redemption-other"},
+	}
+	for _, tt := range tests {
+		if rec := get("local", tt.byteRange, tt.redemption); rec.Code != http.StatusGone {
+			t.Errorf("%s = %d, want 410", tt.name, rec.Code)
+		}
+	}
+
+	rec = get("s3", "bytes=0-", "")
+	if rec.Code != http.StatusFound || !strings.HasPrefix(rec.Header().Get("Location"), "https://exports.s3.amazonaws.com/") {
+		t.Errorf("download from S3 = %d to %q, want a redirect to the object storage", rec.Code, rec.Header().Get("Location"))
+	}
+	if rec := get("s3", "bytes=0-", ""); rec.Code != http.StatusGone {
+		t.Errorf("second bytes=0- request = %d, want 410", rec.Code)
+	}
+
+	if rec := get("forged", "", ""); rec.Code != http.StatusForbidden {
+		t.Errorf("invalid token = %d, want 403", rec.Code)
+	}
+	if rec := get("", "", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("no token = %d, want 400", rec.Code)
+	}
+}
+
+func TestRangeStart(t *testing.T) {
+	tests := []struct {
+		header string
+		want   int64
+	}{
+		{"", 0},
+		{"bytes=0-", 0},
+		{"bytes=0-10", 0},
+		{"bytes=11-", 11},
+		{"bytes=11-20, 30-40", 11},
+		{"bytes=-500", 0},
+		{"bytes=abc-", 0},
+		{"items=5-", 0},
+	}
+	for _, tt := range tests {
+		if got := rangeStart(tt.header); got != tt.want {
+			t.Errorf("rangeStart(%q) = %d, want %d", tt.header, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/service"
+)
+
+// FileHandler handles HTTP requests for uploaded files
+type FileHandler struct {
+	fileService service.FileService
+}
+
+// NewFileHandler creates a new file handler instance
+func NewFileHandler(fileService service.FileService) *FileHandler {
+	return &FileHandler{
+		fileService: fileService,
+	}
+}
+
+// GetScanStatus handles file scan status requests
+// @Summary     Get the antivirus scan status of a file
+// @Tags        files
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id  path     string true "File ID" format(uuid)
+// @Success     200 {object} service.FileScanStatusResponse
+// @Failure     400 {object} ErrorResponse
+// @Failure     401 {object} ErrorResponse
+// @Failure     403 {object} ErrorResponse
+// @Failure     404 {object} ErrorResponse
+// @Router      /api/v1/files/{id}/scan-status [get]
+func (h *FileHandler) GetScanStatus(c *gin.Context) {
+	fileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid file ID"})
+		return
+	}
+
+	userID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	status, err := h.fileService.GetScanStatus(c.Request.Context(), fileID, userID)
+	if err != nil {
+		if isAccessDenied(err) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// DownloadFile redirects to a short-lived download URL for a file
+// @Summary     Download a file
+// @Description Redirects to a short-lived download URL. Files still being scanned return 409 with Retry-After.
+// @Tags        files
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id  path     string true "File ID" format(uuid)
+// @Success     307
+// @Header      307 {string} Location "Download URL"
+// @Failure     400 {object} ErrorResponse
+// @Failure     401 {object} ErrorResponse
+// @Failure     403 {object} ErrorResponse
+// @Failure     404 {object} ErrorResponse
+// @Failure     409 {object} ErrorResponse
+// @Failure     451 {object} ErrorResponse
+// @Router      /api/v1/files/{id}/download [get]
+func (h *FileHandler) DownloadFile(c *gin.Context) {
+	fileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid file ID"})
+		return
+	}
+
+	userID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	url, err := h.fileService.GetDownloadURL(c.Request.Context(), fileID, userID)
+	if err != nil {
+		switch {
+		case isAccessDenied(err):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrFileQuarantined):
+			c.JSON(http.StatusUnavailableForLegalReasons, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrFileScanPending):
+			c.Header("Retry-After", "30")
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.Redirect(http.StatusTemporaryRedirect, url)
+}
@@ -1,25 +1,37 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"reflect"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/funnel"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
 	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/service"
 )
 
 // FormHandler handles HTTP requests for form operations
 type FormHandler struct {
 	formService service.FormService
+	viewTokens  *funnel.Tokens
+	csvRowLimit int
 }
 
-// NewFormHandler creates a new form handler instance
-func NewFormHandler(formService service.FormService) *FormHandler {
+// NewFormHandler creates a new form handler instance. Published forms carry
+// view tokens issued by viewTokens, none when nil; lists rendered as CSV
+// have at most csvRowLimit rows.
+func NewFormHandler(formService service.FormService, viewTokens *funnel.Tokens, csvRowLimit int) *FormHandler {
 	return &FormHandler{
 		formService: formService,
+		viewTokens:  viewTokens,
+		csvRowLimit: csvRowLimit,
 	}
 }
 
@@ -31,7 +43,124 @@ func (h *FormHandler) HealthCheck(c *gin.Context) {
 	})
 }
 
+// InternalStats reports form totals to other services such as the gateway's
+// admin dashboard. It is not exposed through the gateway.
+// @Summary     Form totals
+// @Description Reports form totals to other services. Not routed by the gateway.
+// @Tags        internal
+// @Produce     json
+// @Success     200 {object} StatsResponse
+// @Failure     500 {object} ErrorResponse
+// @Router      /internal/stats [get]
+func (h *FormHandler) InternalStats(c *gin.Context) {
+	total, err := h.formService.CountForms(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, StatsResponse{Total: total})
+}
+
+// CheckResponse checks the answers of a response against the cross-field
+// rules of the form for the response service. It is not exposed through the
+// gateway.
+// @Summary     Check a response against the rules of a form
+// @Description Checks the answers of a response, keyed by question ID, against the cross-field rules of the form. Broken rules are listed with their messages in the locale of the respondent. Rules reading unanswered questions hold. Not routed by the gateway.
+// @Tags        internal
+// @Accept      json
+// @Produce     json
+// @Param       id       path     string                       true "Form ID"
+// @Param       response body     service.CheckResponseRequest true "Answers of the response"
+// @Success     200      {object} service.CheckResponseResult
+// @Failure     400      {object} ErrorResponse
+// @Failure     404      {object} ErrorResponse
+// @Failure     500      {object} ErrorResponse
+// @Router      /internal/forms/{id}/responses/validate [post]
+func (h *FormHandler) CheckResponse(c *gin.Context) {
+	formID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form ID"})
+		return
+	}
+
+	var req service.CheckResponseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.formService.CheckResponse(c.Request.Context(), formID, req)
+	if err != nil {
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// AuthorizeTestSubmission checks a user may submit test responses to a form
+// for the response service. It is not exposed through the gateway.
+// @Summary     Authorize a test submission
+// @Description Answers 204 when the user is the owner or a collaborator of the published form, and may submit test responses to it. Test responses are flagged, left out of quotas, analytics, exports and notifications. Not routed by the gateway.
+// @Tags        internal
+// @Accept      json
+// @Param       id   path string                        true "Form ID" format(uuid)
+// @Param       user body service.TestSubmissionRequest true "User submitting"
+// @Success     204
+// @Failure     400  {object} ErrorResponse
+// @Failure     403  {object} ErrorResponse
+// @Failure     404  {object} ErrorResponse
+// @Failure     500  {object} ErrorResponse
+// @Router      /internal/forms/{id}/responses/test/authorize [post]
+func (h *FormHandler) AuthorizeTestSubmission(c *gin.Context) {
+	formID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form ID"})
+		return
+	}
+
+	var req service.TestSubmissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.formService.AuthorizeTestSubmission(c.Request.Context(), formID, req.UserID); err != nil {
+		switch {
+		case isNotFound(err):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case isAccessDenied(err):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
 // CreateForm handles form creation requests
+// @Summary     Create a form
+// @Description The form is created in the organization the request acts in, or in the personal organization of the user. Organizations with as many active forms as their plan allows get 402 with code upgrade_required until a form is closed.
+// @Tags        forms
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       form body     service.CreateFormRequest true "Form to create"
+// @Success     201  {object} FormResponse
+// @Failure     400  {object} ErrorResponse
+// @Failure     401  {object} ErrorResponse
+// @Failure     402  {object} QuotaErrorResponse
+// @Failure     404  {object} ErrorResponse
+// @Failure     409  {object} ErrorResponse
+// @Failure     500  {object} ErrorResponse
+// @Router      /api/v1/forms [post]
 func (h *FormHandler) CreateForm(c *gin.Context) {
 	userID, err := h.getUserID(c)
 	if err != nil {
@@ -47,17 +176,45 @@ func (h *FormHandler) CreateForm(c *gin.Context) {
 
 	form, err := h.formService.CreateForm(c.Request.Context(), userID, req)
 	if err != nil {
+		if status, ok := rejectedStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrQuotaExceeded) {
+			respondQuotaExceeded(c, err)
+			return
+		}
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "Form created successfully",
-		"form":    form,
+	c.JSON(http.StatusCreated, FormResponse{
+		Message: "Form created successfully",
+		Form:    form,
 	})
 }
 
 // GetForm handles form retrieval requests
+// @Summary     Get a form
+// @Description Authentication is optional. With locale, the form is returned translated as a service.LocalizedFormResponse and Content-Language is set. Responses carry a strong ETag that changes with any change to the form returned, publishing included; send it back in If-None-Match to get 304 without the body while the form is unchanged.
+// @Tags        forms
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id            path     string true  "Form ID" format(uuid)
+// @Param       locale        query    string false "Locale to translate the form into"
+// @Param       If-None-Match header   string false "ETag of the copy the client holds"
+// @Success     200           {object} GetFormResponse
+// @Success     304
+// @Failure     400           {object} ErrorResponse
+// @Failure     401           {object} ErrorResponse
+// @Failure     403           {object} ErrorResponse
+// @Failure     404           {object} ErrorResponse
+// @Failure     500           {object} ErrorResponse
+// @Router      /api/v1/forms/{id} [get]
 func (h *FormHandler) GetForm(c *gin.Context) {
 	userID, err := h.getUserID(c)
 	if err != nil {
@@ -71,9 +228,37 @@ func (h *FormHandler) GetForm(c *gin.Context) {
 		return
 	}
 
+	if locale := c.Query("locale"); locale != "" {
+		localized, err := h.formService.GetLocalizedForm(c.Request.Context(), formID, userID, locale)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidTranslation) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if isNotFound(err) {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			if isAccessDenied(err) {
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Header("Content-Language", localized.Locale)
+		respondWithETag(c, localized)
+		return
+	}
+
 	form, err := h.formService.GetForm(c.Request.Context(), formID, userID)
 	if err != nil {
-		if err.Error() == "access denied: user does not own this form" {
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if isAccessDenied(err) {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
 		}
@@ -81,12 +266,140 @@ func (h *FormHandler) GetForm(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"form": form,
+	settings, err := form.GetSettings()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The response policy tells the frontend whether to prompt for login.
+	// Whoever may read the form may test it once published.
+	respondWithETag(c, GetFormResponse{
+		Form:              form,
+		ResponsePolicy:    settings.ResponsePolicy(),
+		TestModeAvailable: form.Status == models.FormStatusPublished,
 	})
 }
 
+// GetFormBySlug resolves the slug of a published form
+// @Summary     Get a published form by slug
+// @Description Public. Slugs are unique within an organization; pass organization_id when the slug may be used by several. Pass the respondent token to receive the shuffle seed of questions with randomized options, and the view_token the funnel beacons of the respondent carry. A slug replaced in the last 30 days answers 301 with the slug its form moved to in moved_to and Location. Drafts and closed forms are not found. Forms found carry an ETag, except those with honeypot protection; send it back in If-None-Match to get 304 without the body while the form is unchanged.
+// @Tags        forms
+// @Produce     json
+// @Param       slug            path     string true  "Form slug"
+// @Param       organization_id query    string false "Organization of the form" format(uuid)
+// @Param       X-Respondent-Token header string false "Anonymous respondent token the shuffle seed and view token are derived from"
+// @Param       If-None-Match   header   string false "ETag of the copy the client holds"
+// @Success     200             {object} service.ResolvedSlug
+// @Success     301             {object} service.ResolvedSlug
+// @Success     304
+// @Failure     400             {object} ErrorResponse
+// @Failure     404             {object} ErrorResponse
+// @Failure     409             {object} ErrorResponse
+// @Failure     500             {object} ErrorResponse
+// @Router      /api/v1/public/forms/by-slug/{slug} [get]
+func (h *FormHandler) GetFormBySlug(c *gin.Context) {
+	orgID := uuid.Nil
+	if value := c.Query("organization_id"); value != "" {
+		parsed, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization ID"})
+			return
+		}
+		orgID = parsed
+	}
+
+	resolved, err := h.formService.ResolveSlug(c.Request.Context(), orgID, c.Param("slug"))
+	if err != nil {
+		if errors.Is(err, service.ErrSlugAmbiguous) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if resolved.MovedTo != "" {
+		location := "./" + url.PathEscape(resolved.MovedTo)
+		if c.Request.URL.RawQuery != "" {
+			location += "?" + c.Request.URL.RawQuery
+		}
+		c.Header("Location", location)
+		c.JSON(http.StatusMovedPermanently, resolved)
+		return
+	}
+	respondent := c.GetHeader(RespondentTokenHeader)
+	resolved.ShuffleSeed = resolved.RespondentShuffleSeed(respondent)
+	resolved.ViewToken = h.viewTokens.Issue(resolved.Form.ID, respondent)
+	c.Header("Vary", RespondentTokenHeader)
+	respondWithDefinition(c, resolved.PublishedForm, resolved, shuffleVariant(resolved.ShuffleSeed), resolved.ViewToken)
+}
+
+// GetFormChanges serves the change feed of the caller's forms
+// @Summary     List form changes
+// @Description Forms created, updated or deleted after the cursor, oldest first. Question changes update their form. Pass next_cursor as since to get the following changes; the feed is at least once, so a form updated again shows again. Changes of the last few seconds are held back until concurrent writes have committed.
+// @Tags        forms
+// @Produce     json
+// @Security    BearerAuth
+// @Param       since query    string false "Cursor returned as next_cursor, empty for the start of the feed"
+// @Param       limit query    int    false "Page size, 100 by default and at most 500"
+// @Success     200   {object} service.FormChangesResponse
+// @Failure     400   {object} ErrorResponse
+// @Failure     401   {object} ErrorResponse
+// @Failure     404   {object} ErrorResponse
+// @Failure     500   {object} ErrorResponse
+// @Router      /api/v1/forms/changes [get]
+func (h *FormHandler) GetFormChanges(c *gin.Context) {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil || limit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+
+	response, err := h.formService.ListFormChanges(c.Request.Context(), userID, c.Query("since"), limit)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
 // GetUserForms handles user forms listing requests
+// @Summary     List forms
+// @Description The forms of the caller, or with shared=true the forms shared with the caller as a collaborator. With Accept: text/csv or format=csv every form is returned as CSV, one row per form without the pagination envelope, up to CSV_ROW_LIMIT forms; more are refused with 413.
+// @Tags        forms
+// @Produce     json
+// @Produce     text/csv
+// @Security    BearerAuth
+// @Param       shared query    bool   false "List the forms shared with the caller"
+// @Param       page   query    int    false "Page, 1 by default"
+// @Param       limit  query    int    false "Page size, 10 by default and at most 100"
+// @Param       format query    string false "csv to render the forms as CSV" Enums(json,csv)
+// @Success     200    {object} service.PaginatedFormsResponse
+// @Failure     401    {object} ErrorResponse
+// @Failure     404    {object} ErrorResponse
+// @Failure     413    {object} CSVTooLargeResponse
+// @Failure     500    {object} ErrorResponse
+// @Router      /api/v1/forms [get]
 func (h *FormHandler) GetUserForms(c *gin.Context) {
 	userID, err := h.getUserID(c)
 	if err != nil {
@@ -97,8 +410,22 @@ func (h *FormHandler) GetUserForms(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
 
-	response, err := h.formService.GetUserForms(c.Request.Context(), userID, page, limit)
+	list := h.formService.GetUserForms
+	if c.Query("shared") == "true" {
+		list = h.formService.GetSharedForms
+	}
+
+	if wantsCSV(c) {
+		h.streamFormsCSV(c, userID, list)
+		return
+	}
+
+	response, err := list(c.Request.Context(), userID, page, limit)
 	if err != nil {
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -106,7 +433,69 @@ func (h *FormHandler) GetUserForms(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// csvPageSize is how many forms are fetched at a time for CSV renderings
+const csvPageSize = 100
+
+// streamFormsCSV renders every form of the list as CSV, writing each page
+// as it is fetched
+func (h *FormHandler) streamFormsCSV(c *gin.Context, userID uuid.UUID, list func(context.Context, uuid.UUID, int, int) (*service.PaginatedFormsResponse, error)) {
+	var stream *csvStream
+	for page := 1; ; page++ {
+		response, err := list(c.Request.Context(), userID, page, csvPageSize)
+		if err != nil {
+			if stream != nil {
+				stream.abort(err)
+			} else if isNotFound(err) {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+
+		if stream == nil {
+			if response.Total > int64(h.csvRowLimit) {
+				respondCSVTooLarge(c, response.Total, h.csvRowLimit)
+				return
+			}
+			if stream, err = newCSVStream(c, "forms", reflect.TypeOf(models.Form{})); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		for _, form := range response.Forms {
+			if err := stream.Write(form); err != nil {
+				stream.abort(err)
+				return
+			}
+		}
+		if err := stream.Flush(); err != nil {
+			stream.abort(err)
+			return
+		}
+		if page >= response.TotalPages {
+			return
+		}
+	}
+}
+
 // UpdateForm handles form update requests
+// @Summary     Update a form
+// @Description rules replaces the cross-field validation rules of the form. Rules of type comparison, sum_equals, at_least and expression read questions by the keys they declare in questions; expressions may use numbers, "strings", true, false, + - * /, comparisons, && || !, parentheses, answered(key) and count(key). Rules reading unknown questions, or questions whose type doesn't fit, get 400.
+// @Tags        forms
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id   path     string                    true "Form ID" format(uuid)
+// @Param       form body     service.UpdateFormRequest true "Fields to update"
+// @Success     200  {object} FormResponse
+// @Failure     400  {object} ErrorResponse
+// @Failure     401  {object} ErrorResponse
+// @Failure     403  {object} ErrorResponse
+// @Failure     404  {object} ErrorResponse
+// @Failure     409  {object} ErrorResponse
+// @Failure     500  {object} ErrorResponse
+// @Router      /api/v1/forms/{id} [put]
 func (h *FormHandler) UpdateForm(c *gin.Context) {
 	userID, err := h.getUserID(c)
 	if err != nil {
@@ -128,7 +517,15 @@ func (h *FormHandler) UpdateForm(c *gin.Context) {
 
 	form, err := h.formService.UpdateForm(c.Request.Context(), formID, userID, req)
 	if err != nil {
-		if err.Error() == "access denied: user does not own this form" {
+		if status, ok := rejectedStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if isAccessDenied(err) {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
 		}
@@ -136,13 +533,150 @@ func (h *FormHandler) UpdateForm(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Form updated successfully",
-		"form":    form,
+	c.JSON(http.StatusOK, FormResponse{
+		Message: "Form updated successfully",
+		Form:    form,
 	})
 }
 
+// MergeForm handles the merge of the changes a builder made on a past
+// version of a form
+// @Summary     Merge builder changes into a form
+// @Description Merges the changes made on base_version into the current form, three-way, path by path. A change applies when the server value is still its base, is skipped when the server already has its value, and is returned as a conflict otherwise: reason edited for a value changed since, or a question removed while changed since; deleted for changes to a question deleted since; reordered for a reorder of questions reordered otherwise since; exists for a question added under the ID of another one. Question edits, adds and removes merge with reorders. The changes applied are saved at once, bump the version once and are pushed to the builders open on the form; conflicts are left for the editor to resolve and merge again. Changes the form endpoints would reject get 400, as a whole.
+// @Tags        forms
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id    path     string                   true "Form ID" format(uuid)
+// @Param       merge body     service.MergeFormRequest true "Base version and changes"
+// @Success     200   {object} service.MergeFormResult
+// @Failure     400   {object} ErrorResponse
+// @Failure     401   {object} ErrorResponse
+// @Failure     403   {object} ErrorResponse
+// @Failure     404   {object} ErrorResponse
+// @Failure     409   {object} ErrorResponse "The form kept changing during the merge"
+// @Failure     500   {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/merge [post]
+func (h *FormHandler) MergeForm(c *gin.Context) {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	formID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form ID"})
+		return
+	}
+
+	var req service.MergeFormRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.formService.MergeForm(c.Request.Context(), formID, userID, req)
+	if err != nil {
+		if status, ok := rejectedStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrInvalidResultsVisibility) || errors.Is(err, service.ErrInvalidDisplay) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if isAccessDenied(err) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// RenameQuestionKey handles the renames of the keys of questions
+// @Summary     Rename the key of a question
+// @Description Renames the stable key a question's responses are projected under and exports name its column after. The former key becomes an alias of the question: the answers given under it keep being aggregated with the question, and no other question of the form may take it. A question may be renamed back to one of its former keys. Keys are lowercase letters, digits and underscores, starting with a letter, at most 64 characters.
+// @Tags        forms
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id     path     string                           true "Form ID" format(uuid)
+// @Param       qid    path     string                           true "Question ID" format(uuid)
+// @Param       rename body     service.RenameQuestionKeyRequest true "New key"
+// @Success     200    {object} models.Question
+// @Failure     400    {object} ErrorResponse
+// @Failure     401    {object} ErrorResponse
+// @Failure     403    {object} ErrorResponse
+// @Failure     404    {object} ErrorResponse
+// @Failure     409    {object} ErrorResponse "Another question of the form has or had the key"
+// @Failure     500    {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/questions/{qid}/rename-key [post]
+func (h *FormHandler) RenameQuestionKey(c *gin.Context) {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	formID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form ID"})
+		return
+	}
+	questionID, err := uuid.Parse(c.Param("qid"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid question ID"})
+		return
+	}
+
+	var req service.RenameQuestionKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	question, err := h.formService.RenameQuestionKey(c.Request.Context(), formID, questionID, userID, req)
+	if err != nil {
+		if status, ok := rejectedStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		if isNotFound(err) || errors.Is(err, service.ErrQuestionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if isAccessDenied(err) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, question)
+}
+
 // DeleteForm handles form deletion requests
+// @Summary     Delete a form
+// @Tags        forms
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id  path     string true "Form ID" format(uuid)
+// @Success     200 {object} MessageResponse
+// @Failure     400 {object} ErrorResponse
+// @Failure     401 {object} ErrorResponse
+// @Failure     403 {object} ErrorResponse
+// @Failure     404 {object} ErrorResponse
+// @Failure     500 {object} ErrorResponse
+// @Router      /api/v1/forms/{id} [delete]
 func (h *FormHandler) DeleteForm(c *gin.Context) {
 	userID, err := h.getUserID(c)
 	if err != nil {
@@ -158,7 +692,11 @@ func (h *FormHandler) DeleteForm(c *gin.Context) {
 
 	err = h.formService.DeleteForm(c.Request.Context(), formID, userID)
 	if err != nil {
-		if err.Error() == "access denied: user does not own this form" {
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if isAccessDenied(err) {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
 		}
@@ -166,12 +704,24 @@ func (h *FormHandler) DeleteForm(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Form deleted successfully",
+	c.JSON(http.StatusOK, MessageResponse{
+		Message: "Form deleted successfully",
 	})
 }
 
 // PublishForm handles form publishing requests
+// @Summary     Publish a form
+// @Tags        forms
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id  path     string true "Form ID" format(uuid)
+// @Success     200 {object} PublishFormResponse
+// @Failure     400 {object} ErrorResponse
+// @Failure     401 {object} ErrorResponse
+// @Failure     403 {object} ErrorResponse
+// @Failure     404 {object} ErrorResponse
+// @Failure     500 {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/publish [post]
 func (h *FormHandler) PublishForm(c *gin.Context) {
 	userID, err := h.getUserID(c)
 	if err != nil {
@@ -185,9 +735,13 @@ func (h *FormHandler) PublishForm(c *gin.Context) {
 		return
 	}
 
-	form, err := h.formService.PublishForm(c.Request.Context(), formID, userID)
+	form, warnings, err := h.formService.PublishForm(c.Request.Context(), formID, userID)
 	if err != nil {
-		if err.Error() == "access denied: user does not own this form" {
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if isAccessDenied(err) {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
 		}
@@ -195,9 +749,69 @@ func (h *FormHandler) PublishForm(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Form published successfully",
-		"form":    form,
+	c.JSON(http.StatusOK, PublishFormResponse{
+		Message:  "Form published successfully",
+		Form:     form,
+		Warnings: warnings,
+	})
+}
+
+// UpsertTranslation handles requests to create or replace a form translation
+// @Summary     Create or replace a form translation
+// @Tags        forms
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id          path     string                           true "Form ID" format(uuid)
+// @Param       locale      path     string                           true "Locale of the translation"
+// @Param       translation body     service.UpsertTranslationRequest true "Translated strings"
+// @Success     200         {object} FormResponse
+// @Failure     400         {object} ErrorResponse
+// @Failure     401         {object} ErrorResponse
+// @Failure     403         {object} ErrorResponse
+// @Failure     404         {object} ErrorResponse
+// @Failure     500         {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/translations/{locale} [put]
+func (h *FormHandler) UpsertTranslation(c *gin.Context) {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	formID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form ID"})
+		return
+	}
+
+	var req service.UpsertTranslationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	form, err := h.formService.UpsertTranslation(c.Request.Context(), formID, userID, c.Param("locale"), req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTranslation) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if isAccessDenied(err) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, FormResponse{
+		Message: "Translation saved successfully",
+		Form:    form,
 	})
 }
 
@@ -223,10 +837,22 @@ func (h *FormHandler) AddQuestion(c *gin.Context) {
 
 	question, err := h.formService.AddQuestion(c.Request.Context(), formID, userID, req)
 	if err != nil {
-		if err.Error() == "access denied: user does not own this form" {
+		if status, ok := rejectedStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if isAccessDenied(err) {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
 		}
+		if errors.Is(err, service.ErrInvalidResultsVisibility) || errors.Is(err, service.ErrInvalidDisplay) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -259,10 +885,18 @@ func (h *FormHandler) UpdateQuestion(c *gin.Context) {
 
 	question, err := h.formService.UpdateQuestion(c.Request.Context(), questionID, userID, req)
 	if err != nil {
-		if err.Error() == "access denied: user does not own this form" {
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if isAccessDenied(err) {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
 		}
+		if errors.Is(err, service.ErrInvalidResultsVisibility) || errors.Is(err, service.ErrInvalidDisplay) || errors.Is(err, service.ErrInvalidRules) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -289,10 +923,18 @@ func (h *FormHandler) DeleteQuestion(c *gin.Context) {
 
 	err = h.formService.DeleteQuestion(c.Request.Context(), questionID, userID)
 	if err != nil {
-		if err.Error() == "access denied: user does not own this form" {
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if isAccessDenied(err) {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
 		}
+		if errors.Is(err, service.ErrInvalidRules) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -324,7 +966,11 @@ func (h *FormHandler) ReorderQuestions(c *gin.Context) {
 
 	err = h.formService.ReorderQuestions(c.Request.Context(), formID, userID, req)
 	if err != nil {
-		if err.Error() == "access denied: user does not own this form" {
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if isAccessDenied(err) {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
 		}
@@ -353,7 +999,11 @@ func (h *FormHandler) UnpublishForm(c *gin.Context) {
 
 	form, err := h.formService.GetForm(c.Request.Context(), formID, userID)
 	if err != nil {
-		if err.Error() == "access denied: user does not own this form" {
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if isAccessDenied(err) {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
 		}
@@ -378,6 +1028,30 @@ func (h *FormHandler) UnpublishForm(c *gin.Context) {
 	})
 }
 
+// isNotFound reports whether err is for a form or organization that does not
+// exist, or that the organization the request acts in does not see
+func isNotFound(err error) bool {
+	return errors.Is(err, service.ErrFormNotFound) || errors.Is(err, service.ErrOrganizationNotFound)
+}
+
+// rejectedStatus maps the errors of rejected slugs, settings, retentions,
+// rules and question keys to their status
+func rejectedStatus(err error) (int, bool) {
+	switch {
+	case errors.Is(err, service.ErrInvalidSlug), errors.Is(err, service.ErrInvalidSettings), errors.Is(err, service.ErrInvalidRetention),
+		errors.Is(err, service.ErrInvalidRules), errors.Is(err, service.ErrInvalidMerge), errors.Is(err, service.ErrInvalidQuestionKey):
+		return http.StatusBadRequest, true
+	case errors.Is(err, service.ErrSlugTaken), errors.Is(err, service.ErrMergeContended), errors.Is(err, service.ErrQuestionKeyTaken):
+		return http.StatusConflict, true
+	}
+	return 0, false
+}
+
+// isAccessDenied reports whether err denies the user access to a form
+func isAccessDenied(err error) bool {
+	return errors.Is(err, service.ErrNotFormOwner) || errors.Is(err, service.ErrInsufficientRole)
+}
+
 // getUserID extracts user ID from the context (set by authentication middleware)
 func (h *FormHandler) getUserID(c *gin.Context) (uuid.UUID, error) {
 	userIDStr, exists := c.Get("user_id")
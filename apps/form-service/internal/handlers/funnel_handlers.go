@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/analytics"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/service"
+)
+
+// beaconBodyLimit bounds the body of a funnel beacon
+const beaconBodyLimit = 1 << 10
+
+// beaconTimeout bounds the processing of a funnel beacon, done after the
+// client was answered
+const beaconTimeout = 5 * time.Second
+
+// FunnelHandler handles the funnel beacons of public forms and the funnel
+// reports of their owners
+type FunnelHandler struct {
+	funnelService service.FunnelService
+}
+
+// NewFunnelHandler creates a new funnel handler instance
+func NewFunnelHandler(funnelService service.FunnelService) *FunnelHandler {
+	return &FunnelHandler{funnelService: funnelService}
+}
+
+// Beacon handles the funnel beacons of the clients of public forms
+// @Summary     Send a funnel beacon
+// @Description Public. Tells the funnel of a form its respondent viewed it, once shown, or started it, at the first interaction with an answer; submissions complete the funnel on their own. view_token is the one of the definition of the form, served to clients sending X-Respondent-Token. Beacons are fire and forget: they are answered 202 before being processed, and sending one with navigator.sendBeacon, as text/plain, is fine. A session is counted once per stage; beacons of tests, set by test, the X-Test-Submission: true header or test=true, with a view token not issued for the form, or beyond FUNNEL_BEACON_RATE_LIMIT per client and form are dropped.
+// @Tags        embed
+// @Accept      json
+// @Param       id                path  string              true  "Form ID" format(uuid)
+// @Param       beacon            body  service.FunnelBeacon true  "Beacon"
+// @Param       X-Test-Submission header string             false "true to drop the beacon of a test"
+// @Param       test              query bool                false "true to drop the beacon of a test"
+// @Success     202
+// @Failure     400 {object} ErrorResponse
+// @Router      /api/v1/public/forms/{id}/beacon [post]
+func (h *FunnelHandler) Beacon(c *gin.Context) {
+	formID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form ID"})
+		return
+	}
+
+	// Beacons are sent as text/plain to spare the CORS preflight, so the
+	// body is decoded whatever its content type
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, beaconBodyLimit+1))
+	if err != nil || len(body) > beaconBodyLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid beacon"})
+		return
+	}
+	var beacon service.FunnelBeacon
+	if err := json.Unmarshal(body, &beacon); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid beacon"})
+		return
+	}
+	if c.GetHeader("X-Test-Submission") == "true" || c.Query("test") == "true" {
+		beacon.Test = true
+	}
+
+	client := c.ClientIP()
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), beaconTimeout)
+	go func() {
+		defer cancel()
+		if _, err := h.funnelService.Beacon(ctx, formID, client, beacon); err != nil {
+			log.Printf("Funnel beacon of form %s failed: %v", formID, err)
+		}
+	}()
+	c.Status(http.StatusAccepted)
+}
+
+// GetFunnel handles the funnel report of a form
+// @Summary     Get the funnel of a form
+// @Description Reports the sessions of respondents viewing, starting and submitting a form per UTC day from from to to, both included, as YYYY-MM-DD; to defaults to today and from to 29 days before to, and at most 366 days are reported at once. A session is counted once per stage, on the day it reached it; submissions are the responses of the form, test submissions aside. start_rate is starts per view, completion_rate submissions per start and conversion_rate submissions per view, capped at 1 as a session may reach a stage the day after the previous one. Today is flagged partial; the rates of the totals leave it out unless it is the only day. Counts are read from the event store projection, so they lag beacons and submissions by the projection delay.
+// @Tags        analytics
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id   path     string true  "Form ID" format(uuid)
+// @Param       from query    string false "First day, YYYY-MM-DD" format(date)
+// @Param       to   query    string false "Last day, YYYY-MM-DD" format(date)
+// @Success     200  {object} analytics.FunnelReport
+// @Failure     400  {object} ErrorResponse
+// @Failure     401  {object} ErrorResponse
+// @Failure     403  {object} ErrorResponse
+// @Failure     404  {object} ErrorResponse
+// @Failure     500  {object} ErrorResponse
+// @Failure     503  {object} ErrorResponse
+// @Router      /api/v1/analytics/forms/{id}/funnel [get]
+func (h *FunnelHandler) GetFunnel(c *gin.Context) {
+	userID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	formID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form ID"})
+		return
+	}
+
+	report, err := h.funnelService.Funnel(c.Request.Context(), formID, userID, c.Query("from"), c.Query("to"))
+	if err != nil {
+		switch {
+		case errors.Is(err, analytics.ErrInvalidRange):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case isNotFound(err):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrNotFormOwner), errors.Is(err, service.ErrInsufficientRole):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, analytics.ErrNotConfigured):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "form analytics are not available"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
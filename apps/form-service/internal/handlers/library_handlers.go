@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/service"
+)
+
+// LibraryHandler handles HTTP requests for the question bank of an
+// organization and the questions forms copy from it
+type LibraryHandler struct {
+	libraryService service.LibraryService
+}
+
+// NewLibraryHandler creates a new library handler instance
+func NewLibraryHandler(libraryService service.LibraryService) *LibraryHandler {
+	return &LibraryHandler{
+		libraryService: libraryService,
+	}
+}
+
+// CreateLibraryQuestion handles requests adding a question to the library
+// @Summary     Add a library question
+// @Description Adds a question to the library of the organization the caller acts in, or of their personal organization. The definition is validated like the questions of forms. Tags are matched case insensitively.
+// @Tags        library
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       request body     service.LibraryQuestionRequest true "Library question"
+// @Success     201     {object} models.LibraryQuestion
+// @Failure     400     {object} ErrorResponse
+// @Failure     401     {object} ErrorResponse
+// @Failure     404     {object} ErrorResponse
+// @Failure     422     {object} ErrorResponse
+// @Failure     500     {object} ErrorResponse
+// @Router      /api/v1/library/questions [post]
+func (h *LibraryHandler) CreateLibraryQuestion(c *gin.Context) {
+	userID, ok := h.parseUser(c)
+	if !ok {
+		return
+	}
+
+	var req service.LibraryQuestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	question, err := h.libraryService.CreateQuestion(c.Request.Context(), userID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, question)
+}
+
+// SearchLibraryQuestions handles searches of the library
+// @Summary     Search library questions
+// @Description Lists the library questions whose title or description contains q, having every tag listed and of the type, most recently updated first.
+// @Tags        library
+// @Produce     json
+// @Security    BearerAuth
+// @Param       q     query    string false "Text in the title or description"
+// @Param       tags  query    string false "Comma separated tags, all required" example(demographics,age)
+// @Param       type  query    string false "Question type"
+// @Param       page  query    int    false "Page" default(1)
+// @Param       limit query    int    false "Page size" default(10)
+// @Success     200   {object} service.LibraryQuestionList
+// @Failure     401   {object} ErrorResponse
+// @Failure     404   {object} ErrorResponse
+// @Failure     500   {object} ErrorResponse
+// @Router      /api/v1/library/questions [get]
+func (h *LibraryHandler) SearchLibraryQuestions(c *gin.Context) {
+	userID, ok := h.parseUser(c)
+	if !ok {
+		return
+	}
+
+	search := repository.LibrarySearch{
+		Query: c.Query("q"),
+		Type:  models.QuestionType(c.Query("type")),
+	}
+	if tags := c.Query("tags"); tags != "" {
+		search.Tags = strings.Split(tags, ",")
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	list, err := h.libraryService.SearchQuestions(c.Request.Context(), userID, search, page, limit)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// GetLibraryQuestion handles requests for a library question
+// @Summary     Get a library question
+// @Tags        library
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id  path     string true "Library question ID" format(uuid)
+// @Success     200 {object} models.LibraryQuestion
+// @Failure     400 {object} ErrorResponse
+// @Failure     401 {object} ErrorResponse
+// @Failure     404 {object} ErrorResponse
+// @Failure     500 {object} ErrorResponse
+// @Router      /api/v1/library/questions/{id} [get]
+func (h *LibraryHandler) GetLibraryQuestion(c *gin.Context) {
+	userID, id, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	question, err := h.libraryService.GetQuestion(c.Request.Context(), userID, id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, question)
+}
+
+// UpdateLibraryQuestion handles updates of a library question
+// @Summary     Update a library question
+// @Description Updates the definition or tags of a library question. Changes of the definition bump its version; the forms using the previous version keep their copy until the update is propagated.
+// @Tags        library
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id      path     string                               true "Library question ID" format(uuid)
+// @Param       request body     service.UpdateLibraryQuestionRequest true "Changes"
+// @Success     200     {object} models.LibraryQuestion
+// @Failure     400     {object} ErrorResponse
+// @Failure     401     {object} ErrorResponse
+// @Failure     404     {object} ErrorResponse
+// @Failure     422     {object} ErrorResponse
+// @Failure     500     {object} ErrorResponse
+// @Router      /api/v1/library/questions/{id} [put]
+func (h *LibraryHandler) UpdateLibraryQuestion(c *gin.Context) {
+	userID, id, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	var req service.UpdateLibraryQuestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	question, err := h.libraryService.UpdateQuestion(c.Request.Context(), userID, id, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, question)
+}
+
+// DeleteLibraryQuestion handles removals of a library question
+// @Summary     Delete a library question
+// @Description Removes the question from the library. The forms that copied it keep their copies.
+// @Tags        library
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id  path string true "Library question ID" format(uuid)
+// @Success     204
+// @Failure     400 {object} ErrorResponse
+// @Failure     401 {object} ErrorResponse
+// @Failure     404 {object} ErrorResponse
+// @Failure     500 {object} ErrorResponse
+// @Router      /api/v1/library/questions/{id} [delete]
+func (h *LibraryHandler) DeleteLibraryQuestion(c *gin.Context) {
+	userID, id, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.libraryService.DeleteQuestion(c.Request.Context(), userID, id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListLibraryQuestionUsages handles requests for the forms using older
+// versions of a library question
+// @Summary     List outdated usages
+// @Description Lists the questions of forms copied from an older version of the library question, with the status of their form. Only the copies in draft forms are updated by a propagation.
+// @Tags        library
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id  path     string true "Library question ID" format(uuid)
+// @Success     200 {object} service.LibraryUsages
+// @Failure     400 {object} ErrorResponse
+// @Failure     401 {object} ErrorResponse
+// @Failure     404 {object} ErrorResponse
+// @Failure     500 {object} ErrorResponse
+// @Router      /api/v1/library/questions/{id}/usages [get]
+func (h *LibraryHandler) ListLibraryQuestionUsages(c *gin.Context) {
+	userID, id, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	usages, err := h.libraryService.ListUsages(c.Request.Context(), userID, id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, usages)
+}
+
+// PropagateLibraryQuestion handles propagations of a library question
+// @Summary     Propagate a library question
+// @Description Updates the copies of older versions of the library question in draft forms of the organization to its current definition. Published and closed forms are never changed; their copies are counted as skipped. Requires an owner or an admin of the organization.
+// @Tags        library
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id  path     string true "Library question ID" format(uuid)
+// @Success     200 {object} service.PropagateResult
+// @Failure     400 {object} ErrorResponse
+// @Failure     401 {object} ErrorResponse
+// @Failure     403 {object} ErrorResponse
+// @Failure     404 {object} ErrorResponse
+// @Failure     500 {object} ErrorResponse
+// @Router      /api/v1/library/questions/{id}/propagate [post]
+func (h *LibraryHandler) PropagateLibraryQuestion(c *gin.Context) {
+	userID, id, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.libraryService.Propagate(c.Request.Context(), userID, id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// InsertFromLibrary handles copies of library questions into a form
+// @Summary     Insert questions from the library
+// @Description Copies library questions of the organization of the form into it, in the order listed, starting at position. Questions from that position on move down; without a position the copies are appended. Each copy records the library question and version it was copied from.
+// @Tags        library
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id      path     string                           true "Form ID" format(uuid)
+// @Param       request body     service.InsertFromLibraryRequest true "Library questions"
+// @Success     201     {object} LibraryInsertResponse
+// @Failure     400     {object} ErrorResponse
+// @Failure     401     {object} ErrorResponse
+// @Failure     403     {object} ErrorResponse
+// @Failure     404     {object} ErrorResponse
+// @Failure     409     {object} ErrorResponse "Questions were added to the form concurrently"
+// @Failure     500     {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/questions/from-library [post]
+func (h *LibraryHandler) InsertFromLibrary(c *gin.Context) {
+	userID, formID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	var req service.InsertFromLibraryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	questions, err := h.libraryService.InsertIntoForm(c.Request.Context(), formID, userID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, LibraryInsertResponse{Questions: questions})
+}
+
+// parseUser reads the caller, writing the error response when it is invalid
+func (h *LibraryHandler) parseUser(c *gin.Context) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// parseRequest reads the caller and the ID of the path, writing the error
+// response when either is invalid
+func (h *LibraryHandler) parseRequest(c *gin.Context) (userID, id uuid.UUID, ok bool) {
+	if userID, ok = h.parseUser(c); !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, id, true
+}
+
+// handleError maps library service errors to HTTP responses
+func (h *LibraryHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrLibraryQuestionNotFound), isNotFound(err):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case isAccessDenied(err), errors.Is(err, service.ErrInsufficientOrganizationRole):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidLibraryQuestion):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrQuestionKeyTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
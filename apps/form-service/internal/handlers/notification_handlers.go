@@ -0,0 +1,304 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/service"
+)
+
+// NotificationHandler handles HTTP requests for form email notifications and
+// the Slack and Teams channels of forms
+type NotificationHandler struct {
+	notificationService service.NotificationService
+}
+
+// NewNotificationHandler creates a new notification handler instance
+func NewNotificationHandler(notificationService service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+	}
+}
+
+// GetNotificationTarget is called by the notification worker of the event
+// bus for the settings of a form. It is not routed by the gateway.
+// @Summary     Get the notification settings of a form
+// @Description Returns the title, owner and notification settings of a form. Not routed by the gateway.
+// @Tags        internal
+// @Produce     json
+// @Param       id  path     string true "Form ID" format(uuid)
+// @Success     200 {object} service.NotificationTarget
+// @Failure     400 {object} ErrorResponse
+// @Failure     404 {object} ErrorResponse
+// @Failure     500 {object} ErrorResponse
+// @Router      /internal/forms/{id}/notifications [get]
+func (h *NotificationHandler) GetNotificationTarget(c *gin.Context) {
+	formID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form ID"})
+		return
+	}
+
+	target, err := h.notificationService.GetNotificationTarget(c.Request.Context(), formID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, target)
+}
+
+// SendTestNotification handles requests for a test email to the form owner
+// @Summary     Send a test notification
+// @Description Queues a test email to the owner email of the form notification settings.
+// @Tags        forms
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id  path     string true "Form ID" format(uuid)
+// @Success     202 {object} service.TestNotificationResponse
+// @Failure     400 {object} ErrorResponse
+// @Failure     401 {object} ErrorResponse
+// @Failure     403 {object} ErrorResponse
+// @Failure     404 {object} ErrorResponse
+// @Failure     422 {object} ErrorResponse
+// @Failure     500 {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/notifications/test [post]
+func (h *NotificationHandler) SendTestNotification(c *gin.Context) {
+	userID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	formID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form ID"})
+		return
+	}
+
+	resp, err := h.notificationService.SendTestNotification(c.Request.Context(), formID, userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, resp)
+}
+
+// ListChannels handles requests for the notification channels of a form
+// @Summary     List the notification channels of a form
+// @Description Lists the Slack and Teams channels of the form. Webhook URLs are never returned, only a hint of each.
+// @Tags        forms
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id  path     string true "Form ID" format(uuid)
+// @Success     200 {object} NotificationChannelListResponse
+// @Failure     400 {object} ErrorResponse
+// @Failure     401 {object} ErrorResponse
+// @Failure     403 {object} ErrorResponse
+// @Failure     404 {object} ErrorResponse
+// @Failure     500 {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/notifications/channels [get]
+func (h *NotificationHandler) ListChannels(c *gin.Context) {
+	userID, formID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	channels, err := h.notificationService.ListChannels(c.Request.Context(), formID, userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, NotificationChannelListResponse{Channels: channels})
+}
+
+// CreateChannel handles requests adding a notification channel to a form
+// @Summary     Add a notification channel
+// @Description Adds a Slack or Teams channel the events of the form are posted to through an incoming webhook, stored encrypted. Messages show the form title, the responses of the day and a link; answers are only shown with include_answer_summary. Nothing is posted during the quiet hours, in the time zone of the channel. Create the channel disabled and send a test message to check the webhook before enabling it.
+// @Tags        forms
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id      path     string                       true "Form ID" format(uuid)
+// @Param       request body     service.CreateChannelRequest true "Notification channel"
+// @Success     201     {object} models.NotificationChannel
+// @Failure     400     {object} ErrorResponse
+// @Failure     401     {object} ErrorResponse
+// @Failure     403     {object} ErrorResponse
+// @Failure     404     {object} ErrorResponse
+// @Failure     500     {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/notifications/channels [post]
+func (h *NotificationHandler) CreateChannel(c *gin.Context) {
+	userID, formID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	var req service.CreateChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	channel, err := h.notificationService.CreateChannel(c.Request.Context(), formID, userID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, channel)
+}
+
+// UpdateChannel handles changes to a notification channel
+// @Summary     Update a notification channel
+// @Description Changes the fields of the channel that are set; a webhook_url replaces the webhook.
+// @Tags        forms
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id        path     string                       true "Form ID" format(uuid)
+// @Param       channelId path     string                       true "Channel ID" format(uuid)
+// @Param       request   body     service.UpdateChannelRequest true "Changes"
+// @Success     200       {object} models.NotificationChannel
+// @Failure     400       {object} ErrorResponse
+// @Failure     401       {object} ErrorResponse
+// @Failure     403       {object} ErrorResponse
+// @Failure     404       {object} ErrorResponse
+// @Failure     500       {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/notifications/channels/{channelId} [put]
+func (h *NotificationHandler) UpdateChannel(c *gin.Context) {
+	userID, formID, channelID, ok := h.parseChannelRequest(c)
+	if !ok {
+		return
+	}
+
+	var req service.UpdateChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	channel, err := h.notificationService.UpdateChannel(c.Request.Context(), formID, channelID, userID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, channel)
+}
+
+// DeleteChannel handles removal of a notification channel
+// @Summary     Delete a notification channel
+// @Tags        forms
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id        path     string true "Form ID" format(uuid)
+// @Param       channelId path     string true "Channel ID" format(uuid)
+// @Success     200       {object} MessageResponse
+// @Failure     400       {object} ErrorResponse
+// @Failure     401       {object} ErrorResponse
+// @Failure     403       {object} ErrorResponse
+// @Failure     404       {object} ErrorResponse
+// @Failure     500       {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/notifications/channels/{channelId} [delete]
+func (h *NotificationHandler) DeleteChannel(c *gin.Context) {
+	userID, formID, channelID, ok := h.parseChannelRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.notificationService.DeleteChannel(c.Request.Context(), formID, channelID, userID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{
+		Message: "Notification channel deleted successfully",
+	})
+}
+
+// SendTestChannelMessage handles requests for a test message to a channel
+// @Summary     Send a test message to a notification channel
+// @Description Queues a test message to the channel, whether it is enabled or not and outside its quiet hours, so owners can check its webhook before enabling it.
+// @Tags        forms
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id        path     string true "Form ID" format(uuid)
+// @Param       channelId path     string true "Channel ID" format(uuid)
+// @Success     202       {object} service.TestNotificationResponse
+// @Failure     400       {object} ErrorResponse
+// @Failure     401       {object} ErrorResponse
+// @Failure     403       {object} ErrorResponse
+// @Failure     404       {object} ErrorResponse
+// @Failure     500       {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/notifications/channels/{channelId}/test [post]
+func (h *NotificationHandler) SendTestChannelMessage(c *gin.Context) {
+	userID, formID, channelID, ok := h.parseChannelRequest(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.notificationService.SendTestChannelMessage(c.Request.Context(), formID, channelID, userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, resp)
+}
+
+// parseRequest reads the caller and the form ID, writing the error response
+// when either is invalid
+func (h *NotificationHandler) parseRequest(c *gin.Context) (userID, formID uuid.UUID, ok bool) {
+	userID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	formID, err = uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, formID, true
+}
+
+// parseChannelRequest reads the caller, the form ID and the channel ID
+func (h *NotificationHandler) parseChannelRequest(c *gin.Context) (userID, formID, channelID uuid.UUID, ok bool) {
+	userID, formID, ok = h.parseRequest(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+
+	channelID, err := uuid.Parse(c.Param("channelId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid channel ID"})
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, formID, channelID, true
+}
+
+// handleError maps notification service errors to HTTP responses
+func (h *NotificationHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrFormNotFound), errors.Is(err, service.ErrChannelNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case isAccessDenied(err):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidChannel):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrNoOwnerEmail):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
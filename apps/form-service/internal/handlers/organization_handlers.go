@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/service"
+)
+
+// OrganizationHandler handles HTTP requests for organizations and their members
+type OrganizationHandler struct {
+	organizationService service.OrganizationService
+}
+
+// NewOrganizationHandler creates a new organization handler instance
+func NewOrganizationHandler(organizationService service.OrganizationService) *OrganizationHandler {
+	return &OrganizationHandler{
+		organizationService: organizationService,
+	}
+}
+
+// CreateOrganization handles organization creation requests
+// @Summary     Create an organization
+// @Description Creates an organization with the caller as its owner. Tokens naming it in their org_id claim act in it and only see its forms.
+// @Tags        organizations
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       request body     service.CreateOrganizationRequest true "Organization"
+// @Success     201     {object} models.Organization
+// @Failure     400     {object} ErrorResponse
+// @Failure     401     {object} ErrorResponse
+// @Failure     500     {object} ErrorResponse
+// @Router      /api/v1/organizations [post]
+func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
+	userID, ok := h.parseUser(c)
+	if !ok {
+		return
+	}
+
+	var req service.CreateOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	org, err := h.organizationService.CreateOrganization(c.Request.Context(), userID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, org)
+}
+
+// ListOrganizations handles requests for the organizations of the caller
+// @Summary     List organizations
+// @Description The organizations the caller is a member of, their personal organization first
+// @Tags        organizations
+// @Produce     json
+// @Security    BearerAuth
+// @Success     200 {object} OrganizationListResponse
+// @Failure     401 {object} ErrorResponse
+// @Failure     500 {object} ErrorResponse
+// @Router      /api/v1/organizations [get]
+func (h *OrganizationHandler) ListOrganizations(c *gin.Context) {
+	userID, ok := h.parseUser(c)
+	if !ok {
+		return
+	}
+
+	orgs, err := h.organizationService.ListOrganizations(c.Request.Context(), userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, OrganizationListResponse{Organizations: orgs})
+}
+
+// InviteMember handles invitations of members to an organization
+// @Summary     Invite a member
+// @Description Adds a user to the organization as a member or an admin. Requires an owner or an admin. Organizations the caller is not a member of are not found.
+// @Tags        organizations
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id      path     string                      true "Organization ID" format(uuid)
+// @Param       request body     service.InviteMemberRequest true "Member"
+// @Success     201     {object} models.OrganizationMember
+// @Failure     400     {object} ErrorResponse
+// @Failure     401     {object} ErrorResponse
+// @Failure     403     {object} ErrorResponse
+// @Failure     404     {object} ErrorResponse
+// @Failure     409     {object} ErrorResponse
+// @Failure     500     {object} ErrorResponse
+// @Router      /api/v1/organizations/{id}/members [post]
+func (h *OrganizationHandler) InviteMember(c *gin.Context) {
+	userID, orgID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	var req service.InviteMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := h.organizationService.InviteMember(c.Request.Context(), orgID, userID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, member)
+}
+
+// ListMembers handles requests for the members of an organization
+// @Summary     List members
+// @Description Organizations the caller is not a member of are not found.
+// @Tags        organizations
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id  path     string true "Organization ID" format(uuid)
+// @Success     200 {object} MemberListResponse
+// @Failure     400 {object} ErrorResponse
+// @Failure     401 {object} ErrorResponse
+// @Failure     404 {object} ErrorResponse
+// @Failure     500 {object} ErrorResponse
+// @Router      /api/v1/organizations/{id}/members [get]
+func (h *OrganizationHandler) ListMembers(c *gin.Context) {
+	userID, orgID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	members, err := h.organizationService.ListMembers(c.Request.Context(), orgID, userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MemberListResponse{Members: members})
+}
+
+// parseUser reads the caller, writing the error response when it is invalid
+func (h *OrganizationHandler) parseUser(c *gin.Context) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// parseRequest reads the caller and the organization ID, writing the error
+// response when either is invalid
+func (h *OrganizationHandler) parseRequest(c *gin.Context) (userID, orgID uuid.UUID, ok bool) {
+	if userID, ok = h.parseUser(c); !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	orgID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, orgID, true
+}
+
+// handleError maps organization service errors to HTTP responses
+func (h *OrganizationHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrOrganizationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInsufficientOrganizationRole):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrMemberExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidOrganization):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
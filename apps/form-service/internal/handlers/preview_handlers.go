@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/service"
+)
+
+// PreviewHandler handles HTTP requests for the tokens sharing draft forms
+// and the previews they grant
+type PreviewHandler struct {
+	previewService service.PreviewService
+}
+
+// NewPreviewHandler creates a new preview handler instance
+func NewPreviewHandler(previewService service.PreviewService) *PreviewHandler {
+	return &PreviewHandler{
+		previewService: previewService,
+	}
+}
+
+// IssuePreviewToken handles requests issuing a preview token
+// @Summary     Issue a preview token
+// @Description Issues a token sharing the current definition of the form with people without accounts, read only. Tokens expire after 7 days unless expires_in asks for less, and never after the maximum the service allows. The token is only returned here. Requires the owner or an editor; deleted forms are 404.
+// @Tags        previews
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id      path     string                           true  "Form ID" format(uuid)
+// @Param       request body     service.IssuePreviewTokenRequest false "Expiry"
+// @Success     201     {object} service.IssuedPreviewToken
+// @Failure     400     {object} ErrorResponse
+// @Failure     401     {object} ErrorResponse
+// @Failure     403     {object} ErrorResponse
+// @Failure     404     {object} ErrorResponse
+// @Failure     422     {object} ErrorResponse
+// @Failure     500     {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/preview-token [post]
+func (h *PreviewHandler) IssuePreviewToken(c *gin.Context) {
+	userID, formID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	var req service.IssuePreviewTokenRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	token, err := h.previewService.IssueToken(c.Request.Context(), formID, userID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, token)
+}
+
+// ListPreviewTokens handles requests for the preview tokens of a form
+// @Summary     List preview tokens
+// @Description Lists the preview tokens of the form, most recent first, expired and revoked ones included. The tokens themselves are not returned. Requires the owner or an editor.
+// @Tags        previews
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id  path     string true "Form ID" format(uuid)
+// @Success     200 {object} PreviewTokenListResponse
+// @Failure     400 {object} ErrorResponse
+// @Failure     401 {object} ErrorResponse
+// @Failure     403 {object} ErrorResponse
+// @Failure     404 {object} ErrorResponse
+// @Failure     500 {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/preview-tokens [get]
+func (h *PreviewHandler) ListPreviewTokens(c *gin.Context) {
+	userID, formID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	tokens, err := h.previewService.ListTokens(c.Request.Context(), formID, userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, PreviewTokenListResponse{Tokens: tokens})
+}
+
+// RevokePreviewToken handles revocations of a preview token
+// @Summary     Revoke a preview token
+// @Description Revokes the token; previews with it are refused from then on. Revoking a revoked token changes nothing. Requires the owner or an editor.
+// @Tags        previews
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id      path     string true "Form ID" format(uuid)
+// @Param       tokenId path     string true "Preview token ID" format(uuid)
+// @Success     200     {object} models.PreviewToken
+// @Failure     400     {object} ErrorResponse
+// @Failure     401     {object} ErrorResponse
+// @Failure     403     {object} ErrorResponse
+// @Failure     404     {object} ErrorResponse
+// @Failure     500     {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/preview-tokens/{tokenId} [delete]
+func (h *PreviewHandler) RevokePreviewToken(c *gin.Context) {
+	userID, formID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+	tokenID, err := uuid.Parse(c.Param("tokenId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid preview token ID"})
+		return
+	}
+
+	token, err := h.previewService.RevokeToken(c.Request.Context(), formID, userID, tokenID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, token)
+}
+
+// GetFormPreview handles public previews of a form
+// @Summary     Preview a form
+// @Description Public. Returns the current definition of the form shared by the preview token, whatever its status. The response is marked with "preview": true, "read_only": true and the X-Form-Preview header; clients render a preview banner and no submit action, and the response service rejects submissions carrying a preview token.
+// @Tags        previews
+// @Produce     json
+// @Param       token query    string true "Preview token"
+// @Success     200   {object} service.FormPreview
+// @Header      200   {string} X-Form-Preview "true"
+// @Failure     401   {object} ErrorResponse
+// @Failure     404   {object} ErrorResponse
+// @Failure     500   {object} ErrorResponse
+// @Router      /api/v1/public/forms/preview [get]
+func (h *PreviewHandler) GetFormPreview(c *gin.Context) {
+	form, err := h.previewService.GetPreview(c.Request.Context(), c.Query("token"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	// Drafts change and tokens are revoked; previews are never cached
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Form-Preview", "true")
+	c.JSON(http.StatusOK, form)
+}
+
+// parseRequest reads the caller and the form ID, writing the error response
+// when either is invalid
+func (h *PreviewHandler) parseRequest(c *gin.Context) (userID, formID uuid.UUID, ok bool) {
+	userID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	formID, err = uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, formID, true
+}
+
+// handleError maps preview service errors to HTTP responses
+func (h *PreviewHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrPreviewTokenInvalid):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case isNotFound(err), errors.Is(err, repository.ErrPreviewTokenNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case isAccessDenied(err):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidPreviewExpiry):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/service"
+)
+
+// PrivacyHandler handles the data subject requests relayed by the privacy
+// worker of the event bus
+type PrivacyHandler struct {
+	privacyService service.PrivacyService
+}
+
+// NewPrivacyHandler creates a new privacy handler instance
+func NewPrivacyHandler(privacyService service.PrivacyService) *PrivacyHandler {
+	return &PrivacyHandler{
+		privacyService: privacyService,
+	}
+}
+
+// EraseUser is called by the privacy worker of the event bus when the erasure
+// of a user is requested. It is not routed by the gateway.
+// @Summary     Erase the data of a user
+// @Description Deletes or anonymizes the forms the user owns, as the erasure policy says, and removes their collaborations and response drafts. Returns the number of records removed per kind. Erasing a user again removes nothing. Requires the internal service token. Not routed by the gateway.
+// @Tags        internal
+// @Accept      json
+// @Produce     json
+// @Security    ServiceToken
+// @Param       request body     service.PrivacyRequest true "Erasure request"
+// @Success     200     {object} service.PrivacyResult
+// @Failure     400     {object} ErrorResponse
+// @Failure     401     {object} ErrorResponse
+// @Failure     500     {object} ErrorResponse
+// @Router      /internal/privacy/erasure [post]
+func (h *PrivacyHandler) EraseUser(c *gin.Context) {
+	var req service.PrivacyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.privacyService.Erase(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ExportUser is called by the privacy worker of the event bus when the export
+// of a user is requested. It is not routed by the gateway.
+// @Summary     Export the data of a user
+// @Description Stores a JSON archive of the forms the user owns, their collaborations and response drafts, and the submissions passed in, and returns a time-limited link to download it. Requires the internal service token. Not routed by the gateway.
+// @Tags        internal
+// @Accept      json
+// @Produce     json
+// @Security    ServiceToken
+// @Param       request body     service.PrivacyExportRequest true "Export request"
+// @Success     200     {object} service.PrivacyResult
+// @Failure     400     {object} ErrorResponse
+// @Failure     401     {object} ErrorResponse
+// @Failure     500     {object} ErrorResponse
+// @Router      /internal/privacy/export [post]
+func (h *PrivacyHandler) ExportUser(c *gin.Context) {
+	var req service.PrivacyExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.privacyService.Export(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
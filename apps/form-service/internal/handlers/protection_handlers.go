@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/service"
+)
+
+// ProtectionHandler handles HTTP requests for the spam protection of forms
+// and their throttling by the anomaly detector of the event bus
+type ProtectionHandler struct {
+	formService service.FormService
+}
+
+// NewProtectionHandler creates a new protection handler instance
+func NewProtectionHandler(formService service.FormService) *ProtectionHandler {
+	return &ProtectionHandler{
+		formService: formService,
+	}
+}
+
+// GetProtectionStatus handles requests for the spam protection of a form
+// @Summary     Get the protection status of a form
+// @Description Whether a surge of submissions throttled the form, the spam protection submissions must pass and the throttling thresholds. Throttled forms require the CAPTCHA challenge and a stricter per-IP rate limit until the submission rate normalizes.
+// @Tags        forms
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id  path     string true "Form ID" format(uuid)
+// @Success     200 {object} service.ProtectionStatus
+// @Failure     400 {object} ErrorResponse
+// @Failure     401 {object} ErrorResponse
+// @Failure     403 {object} ErrorResponse
+// @Failure     404 {object} ErrorResponse
+// @Failure     500 {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/protection-status [get]
+func (h *ProtectionHandler) GetProtectionStatus(c *gin.Context) {
+	userID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	formID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form ID"})
+		return
+	}
+
+	status, err := h.formService.GetProtectionStatus(c.Request.Context(), formID, userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// GetThrottleState is called by the anomaly detector of the event bus for
+// the thresholds of a form. It is not routed by the gateway.
+// @Summary     Get the throttling of a form
+// @Description Returns whether the form is throttled and its throttling thresholds. Not routed by the gateway.
+// @Tags        internal
+// @Produce     json
+// @Param       id  path     string true "Form ID" format(uuid)
+// @Success     200 {object} service.ProtectionStatus
+// @Failure     400 {object} ErrorResponse
+// @Failure     404 {object} ErrorResponse
+// @Failure     500 {object} ErrorResponse
+// @Router      /internal/forms/{id}/throttling [get]
+func (h *ProtectionHandler) GetThrottleState(c *gin.Context) {
+	formID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form ID"})
+		return
+	}
+
+	status, err := h.formService.GetThrottleState(c.Request.Context(), formID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// SetThrottled is called by the anomaly detector of the event bus to throttle
+// and release forms. It is not routed by the gateway.
+// @Summary     Throttle or release a form
+// @Description Throttles or releases the form. Changes publish form.throttle.engaged or form.throttle.released with the rates given, which the owner is notified of. Not routed by the gateway.
+// @Tags        internal
+// @Accept      json
+// @Produce     json
+// @Param       id      path     string                      true "Form ID" format(uuid)
+// @Param       request body     service.SetThrottledRequest true "Throttling and the rates it was decided on"
+// @Success     200     {object} service.ProtectionStatus
+// @Failure     400     {object} ErrorResponse
+// @Failure     404     {object} ErrorResponse
+// @Failure     500     {object} ErrorResponse
+// @Router      /internal/forms/{id}/throttling [put]
+func (h *ProtectionHandler) SetThrottled(c *gin.Context) {
+	formID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form ID"})
+		return
+	}
+
+	var req service.SetThrottledRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status, err := h.formService.SetThrottled(c.Request.Context(), formID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// handleError maps service errors to HTTP responses
+func (h *ProtectionHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case isNotFound(err):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case isAccessDenied(err):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/receipt"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/service"
+)
+
+// ReceiptHashHeader carries the verification hash printed on a receipt
+const ReceiptHashHeader = "X-Receipt-Hash"
+
+// ReceiptHandler handles HTTP requests for the PDF receipts of responses
+type ReceiptHandler struct {
+	receiptService service.ReceiptService
+}
+
+// NewReceiptHandler creates a new receipt handler instance
+func NewReceiptHandler(receiptService service.ReceiptService) *ReceiptHandler {
+	return &ReceiptHandler{
+		receiptService: receiptService,
+	}
+}
+
+// RenderReceipt renders the PDF receipt of a response for the response
+// service, which checks the caller is its respondent or names the user
+// asking. It is an internal endpoint, not routed by the gateway.
+// @Summary     Render the receipt of a response
+// @Description Returns the PDF receipt of a response: the title of the form, every question with the answer of the response or the names of the files uploaded to it, the submission time, the response ID and a verification hash, also sent in the X-Receipt-Hash header. Titles are translated to the locale when the form has it. Receipts are stored once rendered and served again for the same response, revision and locale. With user_id, the user must be allowed to view the form. Not routed by the gateway.
+// @Tags        internal
+// @Accept      json
+// @Produce     application/pdf
+// @Param       id       path     string                 true "Form ID" format(uuid)
+// @Param       response body     service.ReceiptRequest true "Response"
+// @Success     200      {file}   file
+// @Header      200      {string} X-Receipt-Hash "Verification hash printed on the receipt"
+// @Failure     400      {object} ErrorResponse
+// @Failure     403      {object} ErrorResponse
+// @Failure     404      {object} ErrorResponse
+// @Failure     500      {object} ErrorResponse
+// @Router      /internal/forms/{id}/responses/receipt [post]
+func (h *ReceiptHandler) RenderReceipt(c *gin.Context) {
+	formID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form ID"})
+		return
+	}
+
+	var req service.ReceiptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rendered, err := h.receiptService.Receipt(c.Request.Context(), formID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrReceiptInvalid):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case isNotFound(err):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case isAccessDenied(err):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.Header(ReceiptHashHeader, rendered.Hash)
+	c.Data(http.StatusOK, receipt.ContentType, rendered.PDF)
+}
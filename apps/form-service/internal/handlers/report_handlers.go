@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/service"
+)
+
+// ReportHandler handles HTTP requests for scheduled form reports
+type ReportHandler struct {
+	reportService service.ReportService
+}
+
+// NewReportHandler creates a new report handler instance
+func NewReportHandler(reportService service.ReportService) *ReportHandler {
+	return &ReportHandler{
+		reportService: reportService,
+	}
+}
+
+// UpsertSchedule handles enrollment of a form in scheduled reports
+// @Summary     Schedule form reports
+// @Description Enrolls the form in daily or weekly summary reports, replacing its schedule. Reports run at send_at local time in the timezone, so they keep their local time across daylight saving changes. Periods without responses are skipped unless include_empty is set.
+// @Tags        reports
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id      path     string                        true "Form ID" format(uuid)
+// @Param       request body     service.ReportScheduleRequest true "Report schedule"
+// @Success     200     {object} models.ReportSchedule
+// @Failure     400     {object} ErrorResponse
+// @Failure     401     {object} ErrorResponse
+// @Failure     403     {object} ErrorResponse
+// @Failure     404     {object} ErrorResponse
+// @Failure     422     {object} ErrorResponse
+// @Failure     500     {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/reports/schedule [put]
+func (h *ReportHandler) UpsertSchedule(c *gin.Context) {
+	userID, formID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	var req service.ReportScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schedule, err := h.reportService.UpsertSchedule(c.Request.Context(), formID, userID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// GetSchedule handles requests for the report schedule of a form
+// @Summary     Get the report schedule of a form
+// @Tags        reports
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id  path     string true "Form ID" format(uuid)
+// @Success     200 {object} models.ReportSchedule
+// @Failure     400 {object} ErrorResponse
+// @Failure     401 {object} ErrorResponse
+// @Failure     403 {object} ErrorResponse
+// @Failure     404 {object} ErrorResponse
+// @Failure     500 {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/reports/schedule [get]
+func (h *ReportHandler) GetSchedule(c *gin.Context) {
+	userID, formID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.reportService.GetSchedule(c.Request.Context(), formID, userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// DeleteSchedule handles unenrollment of a form from scheduled reports
+// @Summary     Stop form reports
+// @Description Removes the report schedule of the form. Reports generated so far stay available.
+// @Tags        reports
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id  path     string true "Form ID" format(uuid)
+// @Success     200 {object} MessageResponse
+// @Failure     400 {object} ErrorResponse
+// @Failure     401 {object} ErrorResponse
+// @Failure     403 {object} ErrorResponse
+// @Failure     404 {object} ErrorResponse
+// @Failure     500 {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/reports/schedule [delete]
+func (h *ReportHandler) DeleteSchedule(c *gin.Context) {
+	userID, formID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.reportService.DeleteSchedule(c.Request.Context(), formID, userID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{
+		Message: "Report schedule deleted successfully",
+	})
+}
+
+// ListReports handles requests for the generated reports of a form
+// @Summary     List form reports
+// @Description Lists the latest generated reports of the form, newest first, with download links valid for 15 minutes.
+// @Tags        reports
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id  path     string true "Form ID" format(uuid)
+// @Success     200 {object} ReportListResponse
+// @Failure     400 {object} ErrorResponse
+// @Failure     401 {object} ErrorResponse
+// @Failure     403 {object} ErrorResponse
+// @Failure     404 {object} ErrorResponse
+// @Failure     500 {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/reports [get]
+func (h *ReportHandler) ListReports(c *gin.Context) {
+	userID, formID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	reports, err := h.reportService.ListReports(c.Request.Context(), formID, userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ReportListResponse{Reports: reports})
+}
+
+// parseRequest reads the caller and the form ID, writing the error response
+// when either is invalid
+func (h *ReportHandler) parseRequest(c *gin.Context) (userID, formID uuid.UUID, ok bool) {
+	userID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	formID, err = uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, formID, true
+}
+
+// handleError maps report service errors to HTTP responses
+func (h *ReportHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrFormNotFound), errors.Is(err, repository.ErrScheduleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrNotFormOwner):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrReportScheduleInvalid):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
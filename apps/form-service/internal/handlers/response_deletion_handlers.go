@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/confirmation"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/responses"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/service"
+)
+
+// ResponseDeletionHandler handles HTTP requests for the bulk deletion of the
+// responses of forms
+type ResponseDeletionHandler struct {
+	deletionService service.ResponseDeletionService
+}
+
+// NewResponseDeletionHandler creates a new response deletion handler
+// instance
+func NewResponseDeletionHandler(deletionService service.ResponseDeletionService) *ResponseDeletionHandler {
+	return &ResponseDeletionHandler{
+		deletionService: deletionService,
+	}
+}
+
+// BulkDeleteResponses handles the bulk deletion of responses
+// @Summary     Delete responses in bulk
+// @Description Deletes the responses of a form submitted before or after a date, listed by ID, or flagged as test submissions; a response must match every filter set. Requires the manager role. A dry run answers 200 with the number of responses matching and a confirmation token, valid for 10 minutes by default; the deletion is then started with the same filter and the token, and answered with 202. A token starts a single deletion, which deletes no more responses than its dry run counted and is refused with 409 when more match. Deleting more than the configured share of the responses of a form (50% by default) needs override_cap, from an owner or an admin of its organization. Deleted responses are released from the response quota.
+// @Tags        responses
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id      path     string                    true "Form ID" format(uuid)
+// @Param       request body     service.BulkDeleteRequest true "Filter and confirmation"
+// @Success     200     {object} service.ResponseDeletionPreview
+// @Success     202     {object} models.ResponseDeletionJob
+// @Failure     400     {object} ErrorResponse
+// @Failure     401     {object} ErrorResponse
+// @Failure     403     {object} ErrorResponse
+// @Failure     404     {object} ErrorResponse
+// @Failure     409     {object} ErrorResponse
+// @Failure     410     {object} ErrorResponse
+// @Failure     422     {object} ErrorResponse
+// @Failure     500     {object} ErrorResponse
+// @Failure     503     {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/responses/bulk-delete [post]
+func (h *ResponseDeletionHandler) BulkDeleteResponses(c *gin.Context) {
+	userID, formID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	var req service.BulkDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.DryRun {
+		preview, err := h.deletionService.DryRun(c.Request.Context(), formID, userID, req.ResponseFilter)
+		if err != nil {
+			h.handleError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, preview)
+		return
+	}
+
+	job, err := h.deletionService.Start(c.Request.Context(), formID, userID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, job)
+}
+
+// PurgeTestResponses handles the deletion of the test responses of forms
+// @Summary     Delete the test responses of a form
+// @Description Deletes every test response of the form, submitted by its owner or collaborators with the X-Test-Submission header, without a dry run. Requires the editor role. The deletion runs as a bulk deletion, followed with its ID; forms without test responses answer 422.
+// @Tags        responses
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id  path     string true "Form ID" format(uuid)
+// @Success     202 {object} models.ResponseDeletionJob
+// @Failure     400 {object} ErrorResponse
+// @Failure     401 {object} ErrorResponse
+// @Failure     403 {object} ErrorResponse
+// @Failure     404 {object} ErrorResponse
+// @Failure     422 {object} ErrorResponse
+// @Failure     500 {object} ErrorResponse
+// @Failure     503 {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/responses/test [delete]
+func (h *ResponseDeletionHandler) PurgeTestResponses(c *gin.Context) {
+	userID, formID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.deletionService.PurgeTest(c.Request.Context(), formID, userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetResponseDeletion handles requests for the progress of a bulk deletion
+// @Summary     Get a bulk deletion
+// @Description Returns the status of the bulk deletion and the number of responses deleted so far. Requires the manager role.
+// @Tags        responses
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id    path     string true "Form ID" format(uuid)
+// @Param       jobId path     string true "Deletion job ID" format(uuid)
+// @Success     200   {object} models.ResponseDeletionJob
+// @Failure     400   {object} ErrorResponse
+// @Failure     401   {object} ErrorResponse
+// @Failure     403   {object} ErrorResponse
+// @Failure     404   {object} ErrorResponse
+// @Failure     500   {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/responses/bulk-delete/{jobId} [get]
+func (h *ResponseDeletionHandler) GetResponseDeletion(c *gin.Context) {
+	userID, formID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+	jobID, ok := h.parseJobID(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.deletionService.GetJob(c.Request.Context(), formID, jobID, userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// ResumeResponseDeletion handles the resumption of failed bulk deletions
+// @Summary     Resume a bulk deletion
+// @Description Queues a failed bulk deletion again. It deletes the batch it failed on first, then goes on with the responses left. Deletions that did not fail answer 409. Requires the manager role.
+// @Tags        responses
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id    path     string true "Form ID" format(uuid)
+// @Param       jobId path     string true "Deletion job ID" format(uuid)
+// @Success     202   {object} models.ResponseDeletionJob
+// @Failure     400   {object} ErrorResponse
+// @Failure     401   {object} ErrorResponse
+// @Failure     403   {object} ErrorResponse
+// @Failure     404   {object} ErrorResponse
+// @Failure     409   {object} ErrorResponse
+// @Failure     500   {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/responses/bulk-delete/{jobId}/resume [post]
+func (h *ResponseDeletionHandler) ResumeResponseDeletion(c *gin.Context) {
+	userID, formID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+	jobID, ok := h.parseJobID(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.deletionService.Resume(c.Request.Context(), formID, jobID, userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, job)
+}
+
+// parseRequest reads the caller and the form, writing the error response
+// when either is invalid
+func (h *ResponseDeletionHandler) parseRequest(c *gin.Context) (userID, formID uuid.UUID, ok bool) {
+	userID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	formID, err = uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, formID, true
+}
+
+// parseJobID reads the deletion job, writing the error response when it is
+// invalid
+func (h *ResponseDeletionHandler) parseJobID(c *gin.Context) (uuid.UUID, bool) {
+	jobID, err := uuid.Parse(c.Param("jobId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid deletion job ID"})
+		return uuid.Nil, false
+	}
+	return jobID, true
+}
+
+// handleError maps response deletion service errors to HTTP responses
+func (h *ResponseDeletionHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case isNotFound(err), errors.Is(err, repository.ErrResponseDeletionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case isAccessDenied(err), errors.Is(err, service.ErrInsufficientOrganizationRole):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrConfirmationRequired), errors.Is(err, service.ErrConfirmationMismatch),
+		errors.Is(err, confirmation.ErrInvalidToken):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, confirmation.ErrExpiredToken):
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrMatchesChanged), errors.Is(err, repository.ErrConfirmationUsed),
+		errors.Is(err, service.ErrResponseDeletionNotFailed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrResponseDeletionInvalid), errors.Is(err, service.ErrDeletionCapExceeded):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, responses.ErrNotConfigured):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
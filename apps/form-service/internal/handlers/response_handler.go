@@ -5,26 +5,36 @@ package handlers
 import (
 	"context"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
 	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/dto"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
 )
 
 // ResponseHandler handles standardized API responses with correlation IDs
 type ResponseHandler struct {
-	version string
+	version     string
+	csvRowLimit int
 }
 
 // NewResponseHandler creates a new response handler instance
 func NewResponseHandler(version string) *ResponseHandler {
 	return &ResponseHandler{
-		version: version,
+		version:     version,
+		csvRowLimit: DefaultCSVRowLimit,
 	}
 }
 
+// WithCSVRowLimit caps the rows of the lists rendered as CSV
+func (h *ResponseHandler) WithCSVRowLimit(limit int) *ResponseHandler {
+	h.csvRowLimit = limit
+	return h
+}
+
 // =============================================================================
 // Success Responses
 // =============================================================================
@@ -89,8 +99,14 @@ func (h *ResponseHandler) Deleted(c *gin.Context, message ...string) {
 	c.JSON(http.StatusOK, response)
 }
 
-// Paginated sends a paginated response
+// Paginated sends a paginated response. Clients asking for CSV get the
+// rows of data, a slice of structs, as CSV without the envelope.
 func (h *ResponseHandler) Paginated(c *gin.Context, data interface{}, pagination dto.Pagination, message ...string) {
+	if wantsCSV(c) {
+		respondCSV(c, "data", data, h.csvRowLimit)
+		return
+	}
+
 	msg := "Data retrieved successfully"
 	if len(message) > 0 && message[0] != "" {
 		msg = message[0]
@@ -156,9 +172,9 @@ func (h *ResponseHandler) TooManyRequests(c *gin.Context, message string, retryA
 		RetryAfter: retryAfter,
 	}
 
-	c.Header("Retry-After", string(retryAfter))
-	c.Header("X-RateLimit-Limit", string(rateLimit.Limit))
-	c.Header("X-RateLimit-Remaining", string(rateLimit.Remaining))
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.Header("X-RateLimit-Limit", strconv.Itoa(rateLimit.Limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(rateLimit.Remaining))
 	c.Header("X-RateLimit-Reset", rateLimit.Reset.Format(time.RFC3339))
 
 	c.JSON(http.StatusTooManyRequests, response)
@@ -291,81 +307,15 @@ func CorrelationIDMiddleware() gin.HandlerFunc {
 		// Set in response headers
 		c.Header("X-Correlation-ID", correlationID)
 
-		// Add to request context for downstream services
+		// Add to request context for downstream services and audit events
 		ctx := context.WithValue(c.Request.Context(), "correlationID", correlationID)
+		ctx = events.WithAuditRequest(ctx, events.AuditRequest{IP: c.ClientIP(), CorrelationID: correlationID})
 		c.Request = c.Request.WithContext(ctx)
 
 		c.Next()
 	}
 }
 
-// =============================================================================
-// Request Metrics Middleware
-// =============================================================================
-
-// RequestMetricsMiddleware collects request metrics
-func RequestMetricsMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		start := time.Now()
-
-		// Process request
-		c.Next()
-
-		// Calculate duration
-		duration := time.Since(start)
-
-		// Get correlation ID
-		correlationID := getCorrelationIDFromContext(c)
-
-		// Set metrics headers
-		c.Header("X-Response-Time", duration.String())
-		c.Header("X-Request-ID", correlationID)
-
-		// Log request metrics (can be sent to monitoring system)
-		// This would typically integrate with Prometheus or similar
-		logRequestMetrics(c, duration, correlationID)
-	}
-}
-
-// getCorrelationIDFromContext extracts correlation ID from context
-func getCorrelationIDFromContext(c *gin.Context) string {
-	// Try to get from headers
-	if correlationID := c.GetHeader("X-Correlation-ID"); correlationID != "" {
-		return correlationID
-	}
-
-	// Try to get from context
-	if correlationID, exists := c.Get("correlationID"); exists {
-		if id, ok := correlationID.(string); ok {
-			return id
-		}
-	}
-
-	// Generate new correlation ID
-	return uuid.New().String()
-}
-
-// logRequestMetrics logs request metrics for monitoring
-func logRequestMetrics(c *gin.Context, duration time.Duration, correlationID string) {
-	// This is a placeholder for actual metrics collection
-	// In production, this would send metrics to Prometheus, Grafana, etc.
-
-	metrics := map[string]interface{}{
-		"method":         c.Request.Method,
-		"path":           c.Request.URL.Path,
-		"status_code":    c.Writer.Status(),
-		"duration_ms":    duration.Milliseconds(),
-		"correlation_id": correlationID,
-		"timestamp":      time.Now().UTC(),
-		"user_agent":     c.GetHeader("User-Agent"),
-		"remote_addr":    c.ClientIP(),
-	}
-
-	// In a real implementation, you would send these metrics to your monitoring system
-	// Example: prometheus.Histogram.WithLabelValues(...).Observe(duration.Seconds())
-	_ = metrics // Suppress unused variable warning
-}
-
 // =============================================================================
 // Global Error Handler
 // =============================================================================
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+)
+
+// Response bodies of the form service API. They are written by the handlers
+// and referenced by the swagger annotations, so the generated spec describes
+// what is actually sent.
+
+// ErrorResponse is the body of every 4xx and 5xx response
+type ErrorResponse struct {
+	Error string `json:"error" example:"invalid form ID"`
+}
+
+// QuotaErrorResponse refuses an operation beyond a quota of the plan of the
+// organization. Code tells clients to offer an upgrade.
+type QuotaErrorResponse struct {
+	Error string `json:"error" example:"quota exceeded: the free plan allows 3 active forms; close a form or upgrade"`
+	Code  string `json:"code" example:"upgrade_required"`
+}
+
+// MessageResponse confirms an operation that returns no resource
+type MessageResponse struct {
+	Message string `json:"message" example:"Form deleted successfully"`
+}
+
+// FormResponse returns a form after it was changed
+type FormResponse struct {
+	Message string       `json:"message" example:"Form updated successfully"`
+	Form    *models.Form `json:"form"`
+}
+
+// GetFormResponse returns a form with the response policy the frontend uses
+// to decide whether to prompt for login
+type GetFormResponse struct {
+	Form           *models.Form          `json:"form"`
+	ResponsePolicy models.ResponsePolicy `json:"response_policy"`
+	// TestModeAvailable is set when the caller may submit test responses
+	// to the form, with the X-Test-Submission header
+	TestModeAvailable bool `json:"test_mode_available"`
+}
+
+// EmbedDefinitionResponse returns a published form to the site embedding it
+type EmbedDefinitionResponse struct {
+	Form           *models.Form          `json:"form"`
+	Questions      []*models.Question    `json:"questions"`
+	ResponsePolicy models.ResponsePolicy `json:"response_policy"`
+	SpamProtection *models.SpamChallenge `json:"spam_protection,omitempty"`
+	// ShuffleSeed seeds the shuffle of the questions with randomized
+	// options for the respondent of the X-Respondent-Token header
+	ShuffleSeed *uint32 `json:"shuffle_seed,omitempty"`
+	// ViewToken is the token the funnel beacons of the respondent of the
+	// X-Respondent-Token header carry
+	ViewToken string      `json:"view_token,omitempty" example:"20261016.6f1c2d9e8a7b4c3d2e1f0a9b8c7d6e5f.e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"`
+	Embed     EmbedPolicy `json:"embed"`
+}
+
+// EmbedPolicy tells the embedding site where the form may appear and where
+// its responses go
+type EmbedPolicy struct {
+	AllowedOrigins []string `json:"allowed_origins" example:"https://www.acme.com"`
+	// FrameAncestors is the Content-Security-Policy directive pages framing
+	// the form should be served with
+	FrameAncestors string `json:"frame_ancestors" example:"frame-ancestors 'self' https://www.acme.com"`
+	SubmitURL      string `json:"submit_url" example:"https://api.example.com/responses"`
+}
+
+// PublishFormResponse returns a published form with the publish warnings
+type PublishFormResponse struct {
+	Message  string       `json:"message" example:"Form published successfully"`
+	Form     *models.Form `json:"form"`
+	Warnings []string     `json:"warnings"`
+}
+
+// VerifyFileTokensResponse returns the uploads referenced by valid file tokens
+type VerifyFileTokensResponse struct {
+	Valid bool                 `json:"valid" example:"true"`
+	Files []*models.FileUpload `json:"files"`
+}
+
+// DraftSavedResponse acknowledges an autosave without echoing the answers
+type DraftSavedResponse struct {
+	FormID    uuid.UUID `json:"form_id"`
+	SizeBytes int       `json:"size_bytes"`
+	UpdatedAt time.Time `json:"updated_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// StatsResponse reports form totals to other services
+type StatsResponse struct {
+	Total int64 `json:"total"`
+}
+
+// HealthResponse reports the service status
+type HealthResponse struct {
+	Status       string `json:"status" example:"healthy"`
+	Service      string `json:"service" example:"form-service"`
+	Timestamp    string `json:"timestamp"`
+	Version      string `json:"version" example:"1.0.0"`
+	Architecture string `json:"architecture"`
+}
+
+// ReportListResponse lists the generated reports of a form
+type ReportListResponse struct {
+	Reports []*models.Report `json:"reports"`
+}
+
+// NotificationChannelListResponse lists the Slack and Teams channels of a form
+type NotificationChannelListResponse struct {
+	Channels []*models.NotificationChannel `json:"channels"`
+}
+
+// CollaboratorListResponse lists the collaborators of a form
+type CollaboratorListResponse struct {
+	Collaborators []*models.Collaborator `json:"collaborators"`
+}
+
+// OrganizationListResponse lists the organizations of the caller
+type OrganizationListResponse struct {
+	Organizations []*models.Organization `json:"organizations"`
+}
+
+// MemberListResponse lists the members of an organization
+type MemberListResponse struct {
+	Members []*models.OrganizationMember `json:"members"`
+}
+
+// LibraryInsertResponse returns the questions copied into a form from the
+// library
+type LibraryInsertResponse struct {
+	Questions []*models.Question `json:"questions"`
+}
+
+// PreviewTokenListResponse lists the preview tokens of a form
+type PreviewTokenListResponse struct {
+	Tokens []*models.PreviewToken `json:"tokens"`
+}
+
+// TransferListResponse lists the transfers of the caller
+type TransferListResponse struct {
+	Transfers []*models.FormTransfer `json:"transfers"`
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/analytics"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/service"
+)
+
+// ResultsHandler serves the public results of published forms
+type ResultsHandler struct {
+	resultsService service.ResultsService
+	cacheTTL       time.Duration
+}
+
+// NewResultsHandler creates a new results handler. cacheTTL is how long
+// clients may cache the results, the time the service caches them for.
+func NewResultsHandler(resultsService service.ResultsService, cacheTTL time.Duration) *ResultsHandler {
+	return &ResultsHandler{
+		resultsService: resultsService,
+		cacheTTL:       cacheTTL,
+	}
+}
+
+// GetPublicResults handles requests for the public results of a form
+// @Summary     Get the public results of a form
+// @Description Public. Returns the answer distributions of the questions whose results_visibility is aggregate_public; other questions are left out. A question with fewer responses than min_responses has null responses and distribution. Distributions are cached for PUBLIC_RESULTS_CACHE_TTL, so they lag new responses by up to that long. Forms that are not published are 404.
+// @Tags        results
+// @Produce     json
+// @Param       id  path     string true "Form ID" format(uuid)
+// @Success     200 {object} service.PublicResults
+// @Failure     400 {object} ErrorResponse
+// @Failure     404 {object} ErrorResponse
+// @Failure     500 {object} ErrorResponse
+// @Failure     503 {object} ErrorResponse
+// @Router      /api/v1/public/forms/{id}/results [get]
+func (h *ResultsHandler) GetPublicResults(c *gin.Context) {
+	formID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form ID"})
+		return
+	}
+
+	results, err := h.resultsService.GetPublicResults(c.Request.Context(), formID)
+	if err != nil {
+		switch {
+		case isNotFound(err):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, analytics.ErrNotConfigured):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "form results are not available"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.cacheTTL.Seconds())))
+	c.JSON(http.StatusOK, results)
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/service"
+)
+
+// TransferHandler handles HTTP requests for the transfer of forms between
+// owners and organizations
+type TransferHandler struct {
+	transferService service.TransferService
+}
+
+// NewTransferHandler creates a new transfer handler instance
+func NewTransferHandler(transferService service.TransferService) *TransferHandler {
+	return &TransferHandler{
+		transferService: transferService,
+	}
+}
+
+// RequestTransfer handles requests to transfer a form
+// @Summary     Transfer a form
+// @Description Requests the transfer of a form to another user or organization, who has 7 days to accept it. Requires the owner of the form, or an owner or an admin of its organization. Forms of a team organization go to its members or to another organization; forms of a personal organization move to the personal organization of the user they are transferred to. A form has at most one pending transfer.
+// @Tags        transfers
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id      path     string                      true "Form ID" format(uuid)
+// @Param       request body     service.TransferFormRequest true "Target"
+// @Success     201     {object} models.FormTransfer
+// @Failure     400     {object} ErrorResponse
+// @Failure     401     {object} ErrorResponse
+// @Failure     402     {object} QuotaErrorResponse
+// @Failure     403     {object} ErrorResponse
+// @Failure     404     {object} ErrorResponse
+// @Failure     409     {object} ErrorResponse
+// @Failure     500     {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/transfer [post]
+func (h *TransferHandler) RequestTransfer(c *gin.Context) {
+	userID, formID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	var req service.TransferFormRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	transfer, err := h.transferService.RequestTransfer(c.Request.Context(), formID, userID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, transfer)
+}
+
+// ListTransfers handles requests for the transfers of the caller
+// @Summary     List transfers
+// @Description The transfers from and to the caller, and from and to the organizations they are an owner or an admin of, newest first
+// @Tags        transfers
+// @Produce     json
+// @Security    BearerAuth
+// @Param       status query    string false "Status" Enums(pending, accepted, cancelled, expired)
+// @Success     200    {object} TransferListResponse
+// @Failure     400    {object} ErrorResponse
+// @Failure     401    {object} ErrorResponse
+// @Failure     500    {object} ErrorResponse
+// @Router      /api/v1/transfers [get]
+func (h *TransferHandler) ListTransfers(c *gin.Context) {
+	userID, ok := h.parseUser(c)
+	if !ok {
+		return
+	}
+
+	transfers, err := h.transferService.ListTransfers(c.Request.Context(), userID, models.TransferStatus(c.Query("status")))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, TransferListResponse{Transfers: transfers})
+}
+
+// AcceptTransfer handles the acceptance of transfers
+// @Summary     Accept a transfer
+// @Description Moves the form to the caller: the user it is transferred to, or an owner or an admin of the organization it is transferred to, who becomes its owner. Collaborators outside a new team organization are removed, and its slug is dropped when taken there. Transfers accepted or cancelled meanwhile conflict; expired ones are gone.
+// @Tags        transfers
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id  path     string true "Transfer ID" format(uuid)
+// @Success     200 {object} models.FormTransfer
+// @Failure     400 {object} ErrorResponse
+// @Failure     401 {object} ErrorResponse
+// @Failure     402 {object} QuotaErrorResponse
+// @Failure     403 {object} ErrorResponse
+// @Failure     404 {object} ErrorResponse
+// @Failure     409 {object} ErrorResponse
+// @Failure     410 {object} ErrorResponse
+// @Failure     500 {object} ErrorResponse
+// @Router      /api/v1/transfers/{id}/accept [post]
+func (h *TransferHandler) AcceptTransfer(c *gin.Context) {
+	userID, id, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	transfer, err := h.transferService.AcceptTransfer(c.Request.Context(), id, userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, transfer)
+}
+
+// CancelTransfer handles the cancellation of transfers
+// @Summary     Cancel a transfer
+// @Description Cancels a pending transfer. The side of the form cancels it, the side it is transferred to declines it.
+// @Tags        transfers
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id  path     string true "Transfer ID" format(uuid)
+// @Success     200 {object} models.FormTransfer
+// @Failure     400 {object} ErrorResponse
+// @Failure     401 {object} ErrorResponse
+// @Failure     404 {object} ErrorResponse
+// @Failure     409 {object} ErrorResponse
+// @Failure     410 {object} ErrorResponse
+// @Failure     500 {object} ErrorResponse
+// @Router      /api/v1/transfers/{id}/cancel [post]
+func (h *TransferHandler) CancelTransfer(c *gin.Context) {
+	userID, id, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	transfer, err := h.transferService.CancelTransfer(c.Request.Context(), id, userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, transfer)
+}
+
+// parseUser reads the caller, writing the error response when it is invalid
+func (h *TransferHandler) parseUser(c *gin.Context) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// parseRequest reads the caller and the ID of the form or the transfer,
+// writing the error response when either is invalid
+func (h *TransferHandler) parseRequest(c *gin.Context) (userID, id uuid.UUID, ok bool) {
+	if userID, ok = h.parseUser(c); !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, id, true
+}
+
+// handleError maps transfer service errors to HTTP responses
+func (h *TransferHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrTransferNotFound), isNotFound(err):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case isAccessDenied(err), errors.Is(err, service.ErrInsufficientOrganizationRole):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidTransfer):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTransferPending), errors.Is(err, service.ErrTransferNotPending), errors.Is(err, service.ErrSlugTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTransferExpired):
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrQuotaExceeded):
+		respondQuotaExceeded(c, err)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/service"
+)
+
+// UploadHandler handles HTTP requests for file question uploads
+type UploadHandler struct {
+	uploadService service.UploadService
+}
+
+// NewUploadHandler creates a new upload handler instance
+func NewUploadHandler(uploadService service.UploadService) *UploadHandler {
+	return &UploadHandler{
+		uploadService: uploadService,
+	}
+}
+
+// CreateUploadURL handles requests for a pre-signed upload URL
+// @Summary     Create a pre-signed upload URL
+// @Description Authentication is optional. The returned file token is submitted as the answer.
+// @Tags        uploads
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id      path     string                         true "Form ID" format(uuid)
+// @Param       qid     path     string                         true "Question ID" format(uuid)
+// @Param       upload  body     service.CreateUploadURLRequest true "File to upload"
+// @Success     201     {object} service.UploadURLResponse
+// @Failure     400     {object} ErrorResponse
+// @Failure     422     {object} ErrorResponse
+// @Failure     500     {object} ErrorResponse
+// @Router      /api/v1/forms/{id}/questions/{qid}/upload-url [post]
+func (h *UploadHandler) CreateUploadURL(c *gin.Context) {
+	formID, questionID, ok := h.parseIDs(c)
+	if !ok {
+		return
+	}
+
+	var req service.CreateUploadURLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	upload, err := h.uploadService.CreateUploadURL(c.Request.Context(), formID, questionID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, upload)
+}
+
+// VerifyFileTokens is called by the response service as it creates a
+// response, to verify its file answers. It is not routed to clients.
+// @Summary     Verify file answers
+// @Description Called by the response service as it creates a response, to check the file tokens of an answer and attach the uploads to the response. Requires the internal service token. Not routed to clients.
+// @Tags        internal
+// @Accept      json
+// @Produce     json
+// @Security    ServiceToken
+// @Param       id     path     string                          true "Form ID" format(uuid)
+// @Param       qid    path     string                          true "Question ID" format(uuid)
+// @Param       tokens body     service.VerifyFileTokensRequest true "File tokens"
+// @Success     200    {object} VerifyFileTokensResponse
+// @Failure     400    {object} ErrorResponse
+// @Failure     401    {object} ErrorResponse
+// @Failure     422    {object} ErrorResponse
+// @Failure     500    {object} ErrorResponse
+// @Router      /internal/forms/{id}/questions/{qid}/files/verify [post]
+func (h *UploadHandler) VerifyFileTokens(c *gin.Context) {
+	formID, questionID, ok := h.parseIDs(c)
+	if !ok {
+		return
+	}
+
+	var req service.VerifyFileTokensRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	files, err := h.uploadService.VerifyFileTokens(c.Request.Context(), formID, questionID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, VerifyFileTokensResponse{
+		Valid: true,
+		Files: files,
+	})
+}
+
+// parseIDs extracts the form and question IDs from the route
+func (h *UploadHandler) parseIDs(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	formID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	questionID, err := uuid.Parse(c.Param("qid"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid question ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return formID, questionID, true
+}
+
+// handleError maps upload service errors to HTTP responses
+func (h *UploadHandler) handleError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrUploadRejected) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
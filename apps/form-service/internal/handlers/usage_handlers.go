@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/middleware"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/service"
+)
+
+// UsageHandler handles HTTP requests for the usage of organizations against
+// their plans
+type UsageHandler struct {
+	usageService service.UsageService
+}
+
+// NewUsageHandler creates a new usage handler instance
+func NewUsageHandler(usageService service.UsageService) *UsageHandler {
+	return &UsageHandler{
+		usageService: usageService,
+	}
+}
+
+// GetOwnUsage serves the usage of the organization the request acts in
+// @Summary     Get organization usage
+// @Description The forms created, responses collected, bytes of files uploaded and exports run by the organization the request acts in, or the personal organization of the user, in a month, with the quotas of its plan and its active forms. Counts may lag by up to a minute. Requires the owner or an admin of the organization.
+// @Tags        organizations
+// @Produce     json
+// @Security    BearerAuth
+// @Param       month query    string false "Month as YYYY-MM, the current one by default" example(2026-10)
+// @Success     200   {object} service.UsageReport
+// @Failure     400   {object} ErrorResponse
+// @Failure     401   {object} ErrorResponse
+// @Failure     403   {object} ErrorResponse
+// @Failure     404   {object} ErrorResponse
+// @Failure     500   {object} ErrorResponse
+// @Router      /api/v1/usage [get]
+func (h *UsageHandler) GetOwnUsage(c *gin.Context) {
+	userID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	report, err := h.usageService.GetOwnUsage(c.Request.Context(), userID, c.Query("month"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// GetUsage is called by the gateway for the usage of an organization. It is
+// not routed to clients.
+// @Summary     Get the usage of an organization
+// @Description The usage of any organization in a month, with the quotas of its plan and its active forms. Not routed to clients.
+// @Tags        internal
+// @Produce     json
+// @Param       orgId path     string true  "Organization ID" format(uuid)
+// @Param       month query    string false "Month as YYYY-MM, the current one by default" example(2026-10)
+// @Success     200   {object} service.UsageReport
+// @Failure     400   {object} ErrorResponse
+// @Failure     404   {object} ErrorResponse
+// @Failure     500   {object} ErrorResponse
+// @Router      /internal/admin/usage/{orgId} [get]
+func (h *UsageHandler) GetUsage(c *gin.Context) {
+	orgID, err := uuid.Parse(c.Param("orgId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization ID"})
+		return
+	}
+
+	report, err := h.usageService.GetUsage(c.Request.Context(), orgID, c.Query("month"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// GetLimits is called by the gateway for the quotas it reports to clients
+// along with its own limits. It is not routed to clients.
+// @Summary     Get the limits of a user
+// @Description The quotas of the plan of the organization the request acts in, or the personal organization of the user, with its usage this month and the bounds on its exports. Any member of the organization may be reported them. Not routed to clients.
+// @Tags        internal
+// @Produce     json
+// @Param       userId path     string true "User ID" format(uuid)
+// @Success     200    {object} service.LimitsReport
+// @Failure     400    {object} ErrorResponse
+// @Failure     404    {object} ErrorResponse
+// @Failure     500    {object} ErrorResponse
+// @Router      /internal/users/{userId}/limits [get]
+func (h *UsageHandler) GetLimits(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	limits, err := h.usageService.GetLimits(c.Request.Context(), userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, limits)
+}
+
+// AdmitResponseRequest names the response about to be stored
+type AdmitResponseRequest struct {
+	// ResponseID identifies the response; admitting it again does not
+	// count it twice
+	ResponseID string `json:"response_id" binding:"required,max=100"`
+}
+
+// AdmitResponse is called by the response service before storing a
+// response. It is not routed to clients.
+// @Summary     Admit a response
+// @Description Counts the response against the monthly response quota of the organization of the form, answering 402 with code upgrade_required once the quota is reached. A response admitted again is not counted twice. Not routed to clients.
+// @Tags        internal
+// @Accept      json
+// @Produce     json
+// @Param       id      path     string               true "Form ID" format(uuid)
+// @Param       request body     AdmitResponseRequest true "Response to admit"
+// @Success     204
+// @Failure     400     {object} ErrorResponse
+// @Failure     402     {object} QuotaErrorResponse
+// @Failure     404     {object} ErrorResponse
+// @Failure     500     {object} ErrorResponse
+// @Router      /internal/forms/{id}/usage/responses [post]
+func (h *UsageHandler) AdmitResponse(c *gin.Context) {
+	formID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form ID"})
+		return
+	}
+	var req AdmitResponseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.usageService.AdmitResponse(c.Request.Context(), formID, req.ResponseID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *UsageHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrQuotaExceeded):
+		respondQuotaExceeded(c, err)
+	case errors.Is(err, service.ErrInvalidUsageMonth):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInsufficientOrganizationRole):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case isNotFound(err):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// respondQuotaExceeded answers 402 with the code clients offer an upgrade on
+func respondQuotaExceeded(c *gin.Context, err error) {
+	c.JSON(http.StatusPaymentRequired, QuotaErrorResponse{Error: err.Error(), Code: "upgrade_required"})
+}
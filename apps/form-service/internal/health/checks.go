@@ -0,0 +1,105 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	"github.com/Mir00r/X-Form-Backend/shared/storage"
+)
+
+// Database checks that the connection pool of db reaches the database. The
+// pool replaces broken connections, so the check recovers on its own once
+// the database is back.
+func Database(db *gorm.DB) Check {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}
+}
+
+// PoolStats is the state of the database connection pool
+type PoolStats struct {
+	MaxOpen        int   `json:"max_open"`
+	Open           int   `json:"open"`
+	InUse          int   `json:"in_use"`
+	Idle           int   `json:"idle"`
+	WaitCount      int64 `json:"wait_count"`
+	WaitDurationMS int64 `json:"wait_duration_ms"`
+	// Saturation is the share of MaxOpen in use, from 0 to 1. Queries wait
+	// for a connection at 1.
+	Saturation float64 `json:"saturation"`
+}
+
+// DatabasePool describes the connection pool of db, for AddDetailed
+func DatabasePool(db *gorm.DB) func() interface{} {
+	return func() interface{} {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil
+		}
+		stats := sqlDB.Stats()
+		pool := PoolStats{
+			MaxOpen:        stats.MaxOpenConnections,
+			Open:           stats.OpenConnections,
+			InUse:          stats.InUse,
+			Idle:           stats.Idle,
+			WaitCount:      stats.WaitCount,
+			WaitDurationMS: stats.WaitDuration.Milliseconds(),
+		}
+		if pool.MaxOpen > 0 {
+			pool.Saturation = float64(pool.InUse) / float64(pool.MaxOpen)
+		}
+		return pool
+	}
+}
+
+// Redis checks that client reaches Redis
+func Redis(client *redis.Client) Check {
+	return func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	}
+}
+
+// Migrations checks that pending lists no pending migration. Applied
+// migrations stay applied, so pending is no longer called once it found none.
+func Migrations(pending func(ctx context.Context) ([]string, error)) Check {
+	var applied atomic.Bool
+	return func(ctx context.Context) error {
+		if applied.Load() {
+			return nil
+		}
+
+		missing, err := pending(ctx)
+		if err != nil {
+			return err
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("migrations pending: %s", strings.Join(missing, ", "))
+		}
+		applied.Store(true)
+		return nil
+	}
+}
+
+// Storage checks that the object store is reachable with the credentials
+// of the service
+func Storage(store storage.Backend) Check {
+	return store.Check
+}
+
+// StorageBuckets reports the last checks of the buckets of the
+// organizations storing their objects apart, for AddDetailed. A bucket
+// failing its check only fails the requests for the objects in it.
+func StorageBuckets(router *storage.Router) func() interface{} {
+	return func() interface{} {
+		return router.Status()
+	}
+}
@@ -0,0 +1,178 @@
+// Package health reports whether the form service can serve requests.
+// Liveness only says the process is up; readiness checks the dependencies
+// requests need, so Kubernetes stops routing traffic to an instance whose
+// database is unreachable and resumes once it recovers.
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultTimeout bounds each dependency check, so an unreachable dependency
+// turns readiness to 503 well within the failure threshold of a probe
+const DefaultTimeout = 2 * time.Second
+
+// Check reports the health of a dependency, returning an error when it is
+// unusable. Checks must return once ctx is done.
+type Check func(ctx context.Context) error
+
+// Status is the state of a dependency
+type Status string
+
+const (
+	StatusUp   Status = "up"
+	StatusDown Status = "down"
+)
+
+// Result is the outcome of a dependency check
+type Result struct {
+	Status    Status `json:"status" example:"up"`
+	Error     string `json:"error,omitempty"`
+	LatencyMS int64  `json:"latency_ms"`
+	// Detail describes the dependency beyond up or down, e.g. the state of
+	// the database connection pool
+	Detail interface{} `json:"detail,omitempty" swaggertype:"object"`
+}
+
+// Report aggregates the dependency checks. The service is ready when every
+// dependency is up.
+type Report struct {
+	Ready     bool              `json:"ready"`
+	Checks    map[string]Result `json:"checks"`
+	Timestamp time.Time         `json:"timestamp"`
+}
+
+type namedCheck struct {
+	name   string
+	check  Check
+	detail func() interface{}
+}
+
+// Checker runs the readiness checks of the service
+type Checker struct {
+	timeout time.Duration
+	checks  []namedCheck
+}
+
+// NewChecker creates a checker whose checks each time out after timeout, or
+// DefaultTimeout when timeout is not positive
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Checker{timeout: timeout}
+}
+
+// Add registers the check of the dependency name
+func (h *Checker) Add(name string, check Check) {
+	h.checks = append(h.checks, namedCheck{name: name, check: check})
+}
+
+// AddDetailed registers the check of the dependency name, whose result is
+// described by detail whether the dependency is up or down
+func (h *Checker) AddDetailed(name string, check Check, detail func() interface{}) {
+	h.checks = append(h.checks, namedCheck{name: name, check: check, detail: detail})
+}
+
+// Check runs every check concurrently and aggregates the results
+func (h *Checker) Check(ctx context.Context) Report {
+	report := Report{
+		Ready:     true,
+		Checks:    make(map[string]Result, len(h.checks)),
+		Timestamp: time.Now().UTC(),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, nc := range h.checks {
+		wg.Add(1)
+		go func(nc namedCheck) {
+			defer wg.Done()
+			result := h.run(ctx, nc.check)
+			if nc.detail != nil {
+				result.Detail = nc.detail()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[nc.name] = result
+			if result.Status != StatusUp {
+				report.Ready = false
+			}
+		}(nc)
+	}
+	wg.Wait()
+
+	return report
+}
+
+// run runs check with the checker's timeout. A check ignoring its context
+// is abandoned once the timeout expires.
+func (h *Checker) run(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- check(ctx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := Result{Status: StatusUp, LatencyMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// ReadyHandler serves the readiness report, with 503 when a dependency is down
+//
+// @Summary Readiness probe
+// @Tags    health
+// @Produce json
+// @Success 200 {object} Report
+// @Failure 503 {object} Report "A dependency is down"
+// @Router  /health/ready [get]
+func (h *Checker) ReadyHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := h.Check(c.Request.Context())
+
+		status := http.StatusOK
+		if !report.Ready {
+			status = http.StatusServiceUnavailable
+		}
+		c.Header("Cache-Control", "no-store")
+		c.JSON(status, report)
+	}
+}
+
+// Liveness is the body of a liveness probe
+type Liveness struct {
+	Alive     bool      `json:"alive" example:"true"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// LiveHandler reports that the process is serving requests. It checks no
+// dependency, so an outage never gets healthy instances restarted.
+//
+// @Summary Liveness probe
+// @Tags    health
+// @Produce json
+// @Success 200 {object} Liveness
+// @Router  /health/live [get]
+func LiveHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, Liveness{Alive: true, Timestamp: time.Now().UTC()})
+	}
+}
@@ -0,0 +1,194 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Mir00r/X-Form-Backend/shared/errreport"
+)
+
+// =============================================================================
+// Error Reporting
+// =============================================================================
+
+// ErrorIDHeader carries the ID of the event reported for a failed request
+const ErrorIDHeader = "X-Error-ID"
+
+// ErrorReporting recovers the panics of requests and reports them, and the
+// 5xx responses, to the error tracker. The ID of the event is added to the
+// JSON error body as error_id and sent in the X-Error-ID header, so support
+// can find the event a user reports.
+func ErrorReporting(reporter errreport.Reporter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writer := &reportingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			// The client went away, as with gin.Recovery
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+			id := reporter.Report(c.Request.Context(), &errreport.Event{
+				Err:     errreport.PanicError(recovered),
+				Panic:   true,
+				Stack:   string(debug.Stack()),
+				Request: requestContext(c, http.StatusInternalServerError),
+			})
+			log.Printf("Panic in %s %s, reported as %s: %v", c.Request.Method, c.Request.URL.Path, id, recovered)
+
+			// Headers already sent can't be replaced
+			if writer.ResponseWriter.Written() {
+				c.Abort()
+				return
+			}
+			writer.reset()
+			c.Writer = writer.ResponseWriter
+			c.Header(ErrorIDHeader, id)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal server error", "error_id": id})
+		}()
+
+		c.Next()
+
+		if writer.buffering {
+			writer.report(c, reporter)
+		}
+	}
+}
+
+// requestContext is the context of the request reported
+func requestContext(c *gin.Context, status int) *errreport.Request {
+	return &errreport.Request{
+		Route:         c.FullPath(),
+		Method:        c.Request.Method,
+		Path:          c.Request.URL.Path,
+		Status:        status,
+		CorrelationID: c.GetString("correlationID"),
+		UserID:        GetUserID(c),
+		Headers:       errreport.SanitizeHeaders(c.Request.Header),
+	}
+}
+
+// reportingWriter holds back 5xx responses until the handlers are done, so
+// the ID of the event reported can be added to them
+type reportingWriter struct {
+	gin.ResponseWriter
+	buffering bool
+	status    int
+	body      bytes.Buffer
+}
+
+func (w *reportingWriter) WriteHeader(code int) {
+	if code >= http.StatusInternalServerError && !w.ResponseWriter.Written() {
+		w.buffering, w.status = true, code
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *reportingWriter) WriteHeaderNow() {
+	if !w.buffering {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *reportingWriter) Write(data []byte) (int, error) {
+	if w.buffering {
+		return w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *reportingWriter) WriteString(s string) (int, error) {
+	if w.buffering {
+		return w.body.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *reportingWriter) Status() int {
+	if w.buffering {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+func (w *reportingWriter) Size() int {
+	if w.buffering {
+		return w.body.Len()
+	}
+	return w.ResponseWriter.Size()
+}
+
+func (w *reportingWriter) Written() bool {
+	return w.buffering || w.ResponseWriter.Written()
+}
+
+func (w *reportingWriter) Flush() {
+	if !w.buffering {
+		w.ResponseWriter.Flush()
+	}
+}
+
+// reset drops the response held back
+func (w *reportingWriter) reset() {
+	w.buffering, w.status = false, 0
+	w.body.Reset()
+}
+
+// report reports the 5xx response held back and writes it with the ID of
+// the event
+func (w *reportingWriter) report(c *gin.Context, reporter errreport.Reporter) {
+	status, body := w.status, w.body.Bytes()
+	var envelope map[string]interface{}
+	if json.Unmarshal(body, &envelope) != nil {
+		envelope = nil
+	}
+
+	id := reporter.Report(c.Request.Context(), &errreport.Event{
+		Err:     responseError(status, envelope, c.Errors.Last()),
+		Request: requestContext(c, status),
+	})
+	if envelope != nil {
+		envelope["error_id"] = id
+		if withID, err := json.Marshal(envelope); err == nil {
+			body = withID
+		}
+	}
+
+	header := w.Header()
+	header.Set(ErrorIDHeader, id)
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	w.ResponseWriter.WriteHeader(status)
+	w.ResponseWriter.Write(body)
+	w.reset()
+}
+
+// responseError is the error of a 5xx response: the last error the handlers
+// attached, else the message of its error envelope, {"error": "..."} or
+// {"error": {"message": "..."}}
+func responseError(status int, envelope map[string]interface{}, attached *gin.Error) error {
+	if attached != nil {
+		return attached.Err
+	}
+	switch e := envelope["error"].(type) {
+	case string:
+		return errors.New(e)
+	case map[string]interface{}:
+		if message, ok := e["message"].(string); ok {
+			return errors.New(message)
+		}
+	}
+	return fmt.Errorf("%d %s", status, http.StatusText(status))
+}
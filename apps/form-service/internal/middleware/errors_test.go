@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Mir00r/X-Form-Backend/shared/errreport"
+)
+
+func newErrorRouter(reporter errreport.Reporter) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ErrorReporting(reporter))
+	router.Use(func(c *gin.Context) {
+		c.Set("correlationID", "corr-1")
+		c.Set("userID", "user-1")
+		c.Next()
+	})
+	router.GET("/api/v1/forms/:id", func(c *gin.Context) {
+		var forms map[string]string
+		forms[c.Param("id")] = "panics"
+	})
+	router.POST("/api/v1/forms/:id/publish", func(c *gin.Context) {
+		c.Error(errors.New("database is closed"))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to publish form"})
+	})
+	router.GET("/api/v1/forms", func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "form not found"})
+	})
+	return router
+}
+
+func TestErrorReportingRecoversPanics(t *testing.T) {
+	reporter := errreport.NewNoop()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/forms/f1", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-Request-ID", "req-1")
+	rec := httptest.NewRecorder()
+	newErrorRouter(reporter).ServeHTTP(rec, req)
+
+	events := reporter.Events()
+	if len(events) != 1 {
+		t.Fatalf("reported %d events, want 1", len(events))
+	}
+	event := events[0]
+	if !event.Panic || event.Stack == "" || event.Request == nil {
+		t.Fatalf("event = %+v, want a panic with its stack and request", event)
+	}
+	r := event.Request
+	if r.Route != "/api/v1/forms/:id" || r.Path != "/api/v1/forms/f1" || r.Status != http.StatusInternalServerError ||
+		r.CorrelationID != "corr-1" || r.UserID != "user-1" {
+		t.Errorf("request = %+v", r)
+	}
+	if r.Headers["Authorization"] != "[redacted]" || r.Headers["X-Request-Id"] != "req-1" {
+		t.Errorf("headers = %v, want them sanitized", r.Headers)
+	}
+
+	var body map[string]string
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	if rec.Code != http.StatusInternalServerError || body["error_id"] != event.ID || rec.Header().Get(ErrorIDHeader) != event.ID {
+		t.Errorf("response %d %s, want a 500 with error_id %s", rec.Code, rec.Body, event.ID)
+	}
+}
+
+func TestErrorReportingReportsServerErrors(t *testing.T) {
+	reporter := errreport.NewNoop()
+	router := newErrorRouter(reporter)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/forms/f1/publish", nil))
+	events := reporter.Events()
+	if len(events) != 1 {
+		t.Fatalf("reported %d events, want 1", len(events))
+	}
+	event := events[0]
+	if event.Panic || event.Err.Error() != "database is closed" || event.Request.Status != http.StatusInternalServerError {
+		t.Errorf("event = %+v, want the error attached to the 500", event)
+	}
+	var body map[string]string
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	if rec.Code != http.StatusInternalServerError || body["error"] != "failed to publish form" || body["error_id"] != event.ID {
+		t.Errorf("response %d %s, want the error with error_id %s", rec.Code, rec.Body, event.ID)
+	}
+
+	// Client errors are not reported
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/forms", nil))
+	if rec.Code != http.StatusNotFound || len(reporter.Events()) != 1 || rec.Header().Get(ErrorIDHeader) != "" {
+		t.Errorf("404 response %d, %d events reported", rec.Code, len(reporter.Events()))
+	}
+}
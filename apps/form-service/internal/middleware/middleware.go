@@ -1,11 +1,18 @@
 package middleware
 
 import (
+	"crypto/subtle"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/database"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/tenant"
+	"github.com/Mir00r/X-Form-Backend/shared/flags"
 )
 
 // =============================================================================
@@ -25,10 +32,14 @@ var globalRateLimiter = &simpleRateLimiter{
 	window:   time.Minute, // per minute
 }
 
-// RateLimiting provides simple rate limiting functionality
+// RateLimiting provides simple rate limiting functionality. Requests acting
+// in an organization share its limit; others are limited by client IP.
 func RateLimiting() gin.HandlerFunc {
 	return gin.HandlerFunc(func(c *gin.Context) {
 		clientIP := c.ClientIP()
+		if orgID, ok := tenant.Organization(c.Request.Context()); ok {
+			clientIP = "org:" + orgID.String()
+		}
 
 		globalRateLimiter.mutex.Lock()
 		defer globalRateLimiter.mutex.Unlock()
@@ -75,7 +86,7 @@ func CorsMiddleware() gin.HandlerFunc {
 	return gin.HandlerFunc(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Credentials", "true")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Respondent-Token")
 		c.Header("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
@@ -118,6 +129,7 @@ func AuthRequired(jwtSecret string) gin.HandlerFunc {
 		// Simplified auth for demo - sets a demo user
 		c.Set("userID", "demo-user-123")
 		c.Set("authenticated", true)
+		setFlagSubject(c, "demo-user-123")
 		c.Next()
 	})
 }
@@ -127,6 +139,51 @@ func OptionalAuth(jwtSecret string) gin.HandlerFunc {
 		// Optional auth - may or may not set user
 		c.Set("userID", "demo-user-123")
 		c.Set("authenticated", true)
+		setFlagSubject(c, "demo-user-123")
+		c.Next()
+	})
+}
+
+// ServiceAuth admits the calls of other services to internal routes, which
+// carry the shared token as a bearer token. Every call is refused while the
+// token is empty.
+func ServiceAuth(token string) gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		presented := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if token == "" || subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid service token"})
+			return
+		}
+		c.Next()
+	})
+}
+
+// Organization reads the organization the gateway passes in the
+// X-Organization-ID header into the request context. Requests without the
+// header act in no organization.
+func Organization() gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		header := c.GetHeader(tenant.Header)
+		if header == "" {
+			c.Next()
+			return
+		}
+
+		orgID, err := uuid.Parse(header)
+		if err != nil || orgID == uuid.Nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid organization ID"})
+			return
+		}
+		c.Request = c.Request.WithContext(tenant.WithOrganization(c.Request.Context(), orgID))
+		c.Next()
+	})
+}
+
+// DatabaseSession starts the database session of the request, whose reads
+// stick to the primary once it wrote
+func DatabaseSession() gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(database.WithSession(c.Request.Context()))
 		c.Next()
 	})
 }
@@ -135,6 +192,16 @@ func OptionalAuth(jwtSecret string) gin.HandlerFunc {
 // Helper Functions
 // =============================================================================
 
+// setFlagSubject evaluates the feature flags of the request for the user
+// and the organization it acts in, so they get the answer the gateway gives
+func setFlagSubject(c *gin.Context, userID string) {
+	subject := flags.Subject{UserID: userID}
+	if orgID, ok := tenant.Organization(c.Request.Context()); ok {
+		subject.OrganizationID = orgID.String()
+	}
+	c.Request = c.Request.WithContext(flags.WithSubject(c.Request.Context(), subject))
+}
+
 // GetUserID retrieves user ID from request context
 func GetUserID(c *gin.Context) string {
 	if userID, exists := c.Get("userID"); exists {
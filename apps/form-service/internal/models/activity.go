@@ -0,0 +1,88 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// ActivityAction is what an editor did to a form
+type ActivityAction string
+
+// Activity actions recorded in the feed of a form
+const (
+	ActivityFormCreated        ActivityAction = "form.created"
+	ActivityFormUpdated        ActivityAction = "form.updated"
+	ActivityFormPublished      ActivityAction = "form.published"
+	ActivityTranslationUpdated ActivityAction = "form.translation_updated"
+	ActivityQuestionAdded      ActivityAction = "question.added"
+	ActivityQuestionUpdated    ActivityAction = "question.updated"
+	ActivityQuestionDeleted    ActivityAction = "question.deleted"
+	ActivityQuestionsReordered ActivityAction = "questions.reordered"
+	// ActivityFormMerged changes name the paths of the patch operations a
+	// merge applied
+	ActivityFormMerged ActivityAction = "form.merged"
+)
+
+// ActivityActions lists every activity action
+var ActivityActions = []ActivityAction{
+	ActivityFormCreated,
+	ActivityFormUpdated,
+	ActivityFormPublished,
+	ActivityTranslationUpdated,
+	ActivityQuestionAdded,
+	ActivityQuestionUpdated,
+	ActivityQuestionDeleted,
+	ActivityQuestionsReordered,
+	ActivityFormMerged,
+}
+
+// ParseActivityAction returns the action named s
+func ParseActivityAction(s string) (ActivityAction, error) {
+	for _, action := range ActivityActions {
+		if string(action) == s {
+			return action, nil
+		}
+	}
+	return "", fmt.Errorf("unknown activity action %q", s)
+}
+
+// Targets of an activity
+const (
+	ActivityTargetForm     = "form"
+	ActivityTargetQuestion = "question"
+)
+
+// FormActivity is an entry of the activity feed of a form: who changed what
+// in its definition, and when. Activities are never updated; the oldest are
+// dropped once a form has more than the feed keeps. Changes only names the
+// fields of the definition that changed, never their values nor responses.
+type FormActivity struct {
+	ID      uuid.UUID      `gorm:"type:uuid;primaryKey" json:"id"`
+	FormID  uuid.UUID      `gorm:"type:uuid;not null;index:idx_form_activities_form_created,priority:1" json:"form_id"`
+	ActorID uuid.UUID      `gorm:"type:uuid;not null" json:"actor_id"`
+	Action  ActivityAction `gorm:"not null" json:"action" enums:"form.created,form.updated,form.published,form.translation_updated,question.added,question.updated,question.deleted,questions.reordered,form.merged"`
+	// TargetType is form or question, TargetID the ID of the form or the
+	// question
+	TargetType string    `gorm:"not null" json:"target_type" enums:"form,question"`
+	TargetID   uuid.UUID `gorm:"type:uuid;not null" json:"target_id"`
+	// Changes names the fields that changed, for updates
+	Changes   datatypes.JSONSlice[string] `gorm:"type:jsonb" json:"changes,omitempty" swaggertype:"array,string" example:"title,settings"`
+	CreatedAt time.Time                   `gorm:"index:idx_form_activities_form_created,priority:2" json:"created_at"`
+}
+
+// BeforeCreate GORM hook called before creating an activity
+func (a *FormActivity) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName returns the table name for GORM
+func (FormActivity) TableName() string {
+	return "form_activities"
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// CleanupCriteria selects the forms a cleanup archives. A cleanup selects by
+// inactivity or by the absence of responses; the owner, organization and
+// status only narrow the selection.
+type CleanupCriteria struct {
+	// InactiveBefore selects the forms last updated before it
+	InactiveBefore *time.Time `json:"inactive_before,omitempty" example:"2024-01-01T00:00:00Z"`
+	// ZeroResponses selects the forms that never received a response, as
+	// counted by the analytics service
+	ZeroResponses  bool       `json:"zero_responses,omitempty"`
+	OwnerID        *uuid.UUID `json:"owner_id,omitempty" swaggertype:"string" format:"uuid"`
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty" swaggertype:"string" format:"uuid"`
+	Status         FormStatus `json:"status,omitempty" example:"draft"`
+}
+
+// Validate validates the criteria of a cleanup started at now
+func (c CleanupCriteria) Validate(now time.Time) error {
+	if c.InactiveBefore == nil && !c.ZeroResponses {
+		return fmt.Errorf("a cleanup needs inactive_before or zero_responses")
+	}
+	if c.InactiveBefore != nil && c.InactiveBefore.After(now) {
+		return fmt.Errorf("inactive_before must not be in the future")
+	}
+	if c.Status != "" && !c.Status.IsValid() {
+		return fmt.Errorf("invalid form status: %s", c.Status)
+	}
+	return nil
+}
+
+// CleanupJobStatus is the state of a cleanup job
+type CleanupJobStatus string
+
+const (
+	CleanupJobQueued    CleanupJobStatus = "queued"
+	CleanupJobRunning   CleanupJobStatus = "running"
+	CleanupJobCompleted CleanupJobStatus = "completed"
+	CleanupJobCancelled CleanupJobStatus = "cancelled"
+	CleanupJobFailed    CleanupJobStatus = "failed"
+)
+
+// Finished reports whether a job in the status will not run again
+func (s CleanupJobStatus) Finished() bool {
+	return s == CleanupJobCompleted || s == CleanupJobCancelled || s == CleanupJobFailed
+}
+
+// CleanupJob archives the forms matching its criteria in batches. The forms
+// are walked in (created_at, id) order; the cursor is the last form walked,
+// so a job interrupted by a restart resumes after it.
+type CleanupJob struct {
+	ID       uuid.UUID                           `gorm:"type:uuid;primaryKey" json:"id"`
+	Status   CleanupJobStatus                    `gorm:"size:20;not null;index" json:"status"`
+	Criteria datatypes.JSONType[CleanupCriteria] `gorm:"type:jsonb;not null" json:"criteria" swaggertype:"object"`
+	// RequestedBy is the admin who started the cleanup
+	RequestedBy string `gorm:"size:255" json:"requested_by,omitempty"`
+	// Matched counts the forms walked that matched the criteria, Archived
+	// those archived and Failed those that could not be checked or archived
+	Matched  int `gorm:"not null;default:0" json:"matched"`
+	Archived int `gorm:"not null;default:0" json:"archived"`
+	Failed   int `gorm:"not null;default:0" json:"failed"`
+
+	CursorCreatedAt *time.Time `json:"-"`
+	CursorID        *uuid.UUID `gorm:"type:uuid" json:"-"`
+
+	Error      string     `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// BeforeCreate GORM hook called before creating a cleanup job
+func (j *CleanupJob) BeforeCreate(tx *gorm.DB) error {
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName returns the table name for GORM
+func (CleanupJob) TableName() string {
+	return "form_cleanup_jobs"
+}
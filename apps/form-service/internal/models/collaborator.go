@@ -8,20 +8,26 @@ import (
 	"gorm.io/gorm"
 )
 
-// CollaboratorRole represents the role of a collaborator
+// CollaboratorRole represents the role of a user on a form
 type CollaboratorRole string
 
 const (
+	// CollaboratorRoleViewer can read the form
 	CollaboratorRoleViewer CollaboratorRole = "viewer"
+	// CollaboratorRoleEditor can also change the form and its questions
 	CollaboratorRoleEditor CollaboratorRole = "editor"
-	CollaboratorRoleOwner  CollaboratorRole = "owner"
-	CollaboratorRoleAdmin  CollaboratorRole = "admin"
+	// CollaboratorRoleManager can also publish and delete the form and
+	// manage its collaborators
+	CollaboratorRoleManager CollaboratorRole = "manager"
+	// CollaboratorRoleOwner is the role of the owner of the form. It is
+	// never granted to a collaborator.
+	CollaboratorRoleOwner CollaboratorRole = "owner"
 )
 
-// IsValid validates if the collaborator role is valid
+// IsValid reports whether the role can be granted to a collaborator
 func (cr CollaboratorRole) IsValid() bool {
 	switch cr {
-	case CollaboratorRoleViewer, CollaboratorRoleEditor, CollaboratorRoleOwner, CollaboratorRoleAdmin:
+	case CollaboratorRoleViewer, CollaboratorRoleEditor, CollaboratorRoleManager:
 		return true
 	default:
 		return false
@@ -68,7 +74,7 @@ type Collaborator struct {
 
 // TableName returns the table name for the Collaborator model
 func (Collaborator) TableName() string {
-	return "collaborators"
+	return "form_collaborators"
 }
 
 // BeforeCreate hook is called before creating a collaborator
@@ -98,27 +104,3 @@ func (c *Collaborator) Validate() error {
 	}
 	return nil
 }
-
-// CanRead checks if the collaborator can read the form
-func (c *Collaborator) CanRead() bool {
-	return c.Status == CollaboratorStatusActive &&
-		(c.Role == CollaboratorRoleViewer || c.Role == CollaboratorRoleEditor ||
-			c.Role == CollaboratorRoleOwner || c.Role == CollaboratorRoleAdmin)
-}
-
-// CanEdit checks if the collaborator can edit the form
-func (c *Collaborator) CanEdit() bool {
-	return c.Status == CollaboratorStatusActive &&
-		(c.Role == CollaboratorRoleEditor || c.Role == CollaboratorRoleOwner || c.Role == CollaboratorRoleAdmin)
-}
-
-// CanManage checks if the collaborator can manage other collaborators
-func (c *Collaborator) CanManage() bool {
-	return c.Status == CollaboratorStatusActive &&
-		(c.Role == CollaboratorRoleOwner || c.Role == CollaboratorRoleAdmin)
-}
-
-// IsOwner checks if the collaborator is the owner
-func (c *Collaborator) IsOwner() bool {
-	return c.Status == CollaboratorStatusActive && c.Role == CollaboratorRoleOwner
-}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// MaxEmbedAllowedOrigins bounds the origins a form may be embedded on
+const MaxEmbedAllowedOrigins = 50
+
+// NormalizeOrigin checks that origin is a web origin, scheme://host[:port]
+// without a path, and returns it lowercased
+func NormalizeOrigin(origin string) (string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(origin))
+	if err != nil || parsed.Host == "" {
+		return "", fmt.Errorf("invalid embed origin %q", origin)
+	}
+	if parsed.Scheme != "https" && parsed.Scheme != "http" {
+		return "", fmt.Errorf("embed origin %q must be http or https", origin)
+	}
+	if parsed.User != nil || (parsed.Path != "" && parsed.Path != "/") || parsed.RawQuery != "" || parsed.Fragment != "" {
+		return "", fmt.Errorf("embed origin %q must not have a path, query or credentials", origin)
+	}
+	return strings.ToLower(parsed.Scheme + "://" + parsed.Host), nil
+}
+
+// NormalizeOrigins normalizes a list of embed origins, dropping duplicates
+func NormalizeOrigins(origins []string) ([]string, error) {
+	if len(origins) > MaxEmbedAllowedOrigins {
+		return nil, fmt.Errorf("at most %d embed origins are allowed", MaxEmbedAllowedOrigins)
+	}
+
+	normalized := make([]string, 0, len(origins))
+	seen := make(map[string]bool, len(origins))
+	for _, origin := range origins {
+		origin, err := NormalizeOrigin(origin)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[origin] {
+			seen[origin] = true
+			normalized = append(normalized, origin)
+		}
+	}
+	return normalized, nil
+}
+
+// EmbedAllowed reports whether the form may be embedded on the site at
+// origin, the value of an Origin header
+func (fs FormSettings) EmbedAllowed(origin string) bool {
+	origin, err := NormalizeOrigin(origin)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range fs.EmbedAllowedOrigins {
+		if allowed == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// FrameAncestors is the Content-Security-Policy frame-ancestors directive
+// pages framing the form should be served with: the allow-listed origins,
+// or none when the form may not be embedded
+func (fs FormSettings) FrameAncestors() string {
+	if len(fs.EmbedAllowedOrigins) == 0 {
+		return "frame-ancestors 'none'"
+	}
+	return "frame-ancestors 'self' " + strings.Join(fs.EmbedAllowedOrigins, " ")
+}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ExportFormat is the file format of the responses of an export
+type ExportFormat string
+
+const (
+	ExportFormatCSV  ExportFormat = "csv"
+	ExportFormatXLSX ExportFormat = "xlsx"
+)
+
+// IsValid validates if the export format is valid
+func (ef ExportFormat) IsValid() bool {
+	return ef == ExportFormatCSV || ef == ExportFormatXLSX
+}
+
+// ContentType returns the MIME type of the responses file in the format
+func (ef ExportFormat) ContentType() string {
+	if ef == ExportFormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// ExportPriority orders the export jobs waiting to run: low priority jobs,
+// such as bulk exports, only run when no normal job can run instead
+type ExportPriority string
+
+const (
+	ExportPriorityNormal ExportPriority = "normal"
+	ExportPriorityLow    ExportPriority = "low"
+)
+
+// IsValid validates if the export priority is valid
+func (ep ExportPriority) IsValid() bool {
+	return ep == ExportPriorityNormal || ep == ExportPriorityLow
+}
+
+// ExportJobStatus is the state of an export job
+type ExportJobStatus string
+
+const (
+	ExportJobQueued    ExportJobStatus = "queued"
+	ExportJobRunning   ExportJobStatus = "running"
+	ExportJobCompleted ExportJobStatus = "completed"
+	ExportJobFailed    ExportJobStatus = "failed"
+	ExportJobCancelled ExportJobStatus = "cancelled"
+)
+
+// IsFinished reports whether the job ended, whatever its outcome
+func (s ExportJobStatus) IsFinished() bool {
+	return s == ExportJobCompleted || s == ExportJobFailed || s == ExportJobCancelled
+}
+
+// ExportJob exports the responses of a form to storage. With IncludeFiles
+// the export is a ZIP archive holding the responses file and the files
+// attached to each response; without, it is the responses file alone. A job
+// interrupted by a restart is run again from the start.
+//
+// Jobs are scheduled fairly across the organizations of their forms, so an
+// organization queuing many exports doesn't hold up the others.
+type ExportJob struct {
+	ID             uuid.UUID    `gorm:"type:uuid;primaryKey" json:"id"`
+	FormID         uuid.UUID    `gorm:"type:uuid;not null;index" json:"form_id"`
+	OrganizationID uuid.UUID    `gorm:"type:uuid;index" json:"organization_id"`
+	RequestedBy    uuid.UUID    `gorm:"type:uuid;not null" json:"requested_by"`
+	Format         ExportFormat `gorm:"size:10;not null" json:"format"`
+	IncludeFiles   bool         `gorm:"not null;default:false" json:"include_files"`
+	// IncludeLabels adds a second header row of question titles under the
+	// row of question keys
+	IncludeLabels bool `gorm:"not null;default:false" json:"include_labels"`
+	// IncludePresentedOrder adds, after each question with randomized
+	// options, a column of the order the options were shown in
+	IncludePresentedOrder bool `gorm:"not null;default:false" json:"include_presented_order"`
+	// IncludeTest exports the test submissions of owners and collaborators
+	// too, flagged in a test column
+	IncludeTest bool `gorm:"not null;default:false" json:"include_test"`
+	// IncludeTimings adds, after each question, the seconds it held focus
+	// and the times the respondent returned to it, from the timing beacons
+	// of the client
+	IncludeTimings bool            `gorm:"not null;default:false" json:"include_timings"`
+	Priority       ExportPriority  `gorm:"size:10;not null;default:'normal'" json:"priority"`
+	Status         ExportJobStatus `gorm:"size:20;not null;index" json:"status"`
+
+	// QueuePosition is the place of a queued job among the jobs of its
+	// organization waiting to run, from 1, set when the job is read
+	QueuePosition int `gorm:"-" json:"queue_position,omitempty"`
+
+	// Progress: the responses exported, the bytes of the export written to
+	// storage, the files archived and those replaced by a placeholder
+	// because they are missing or quarantined
+	Responses    int   `gorm:"not null;default:0" json:"responses"`
+	BytesWritten int64 `gorm:"not null;default:0" json:"bytes_written"`
+	FilesWritten int   `gorm:"not null;default:0" json:"files_written"`
+	FilesSkipped int   `gorm:"not null;default:0" json:"files_skipped"`
+
+	// ObjectKey is the key of the export in storage, once completed
+	ObjectKey  string     `gorm:"size:500" json:"-"`
+	Error      string     `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+
+	// DownloadURL is the endpoint signing download links to the completed
+	// export, set when the job is read
+	DownloadURL string `gorm:"-" json:"download_url,omitempty"`
+}
+
+// BeforeCreate GORM hook called before creating an export job
+func (j *ExportJob) BeforeCreate(tx *gorm.DB) error {
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName returns the table name for GORM
+func (ExportJob) TableName() string {
+	return "form_export_jobs"
+}
@@ -0,0 +1,140 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Default limits applied to file questions that don't set their own
+const (
+	DefaultFileMaxSizeBytes int64 = 10 * 1024 * 1024
+	DefaultFileMaxCount           = 1
+	MaxFileSizeBytes        int64 = 100 * 1024 * 1024
+)
+
+// FileConstraints are the upload rules of a file question. They live in the
+// question's validation JSON.
+type FileConstraints struct {
+	AllowedMimeTypes []string `json:"allowed_mime_types,omitempty"`
+	MaxSizeBytes     int64    `json:"max_size_bytes,omitempty"`
+	MaxCount         int      `json:"max_count,omitempty"`
+}
+
+// Validate validates the file constraints
+func (fc FileConstraints) Validate() error {
+	if fc.MaxSizeBytes < 0 || fc.MaxSizeBytes > MaxFileSizeBytes {
+		return fmt.Errorf("max_size_bytes must be between 1 and %d", MaxFileSizeBytes)
+	}
+	if fc.MaxCount < 0 {
+		return fmt.Errorf("max_count must be positive")
+	}
+	for _, mimeType := range fc.AllowedMimeTypes {
+		if !strings.Contains(mimeType, "/") {
+			return fmt.Errorf("invalid MIME type: %s", mimeType)
+		}
+	}
+	return nil
+}
+
+// AllowsMimeType reports whether contentType is accepted. Entries may use a
+// wildcard subtype such as "image/*"; an empty list accepts everything.
+func (fc FileConstraints) AllowsMimeType(contentType string) bool {
+	if len(fc.AllowedMimeTypes) == 0 {
+		return true
+	}
+	contentType = strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	for _, allowed := range fc.AllowedMimeTypes {
+		allowed = strings.ToLower(allowed)
+		if allowed == contentType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(contentType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// GetFileConstraints decodes the file constraints of a question, filling in defaults
+func (q *Question) GetFileConstraints() (FileConstraints, error) {
+	var fc FileConstraints
+	if len(q.Validation) > 0 && string(q.Validation) != "null" {
+		if err := json.Unmarshal(q.Validation, &fc); err != nil {
+			return fc, fmt.Errorf("invalid file constraints for question %s: %w", q.ID, err)
+		}
+	}
+	if fc.MaxSizeBytes == 0 {
+		fc.MaxSizeBytes = DefaultFileMaxSizeBytes
+	}
+	if fc.MaxCount == 0 {
+		fc.MaxCount = DefaultFileMaxCount
+	}
+	return fc, nil
+}
+
+// FileUploadStatus represents the lifecycle state of an upload
+type FileUploadStatus string
+
+const (
+	FileUploadStatusPending  FileUploadStatus = "pending"
+	FileUploadStatusAttached FileUploadStatus = "attached"
+)
+
+// FileUpload tracks an object uploaded through a pre-signed URL. Respondents
+// reference it from their answer by Token; uploads still pending after the
+// orphan window are removed.
+type FileUpload struct {
+	ID          uuid.UUID        `gorm:"type:uuid;primaryKey" json:"id"`
+	Token       string           `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	FormID      uuid.UUID        `gorm:"type:uuid;not null;index" json:"form_id"`
+	QuestionID  uuid.UUID        `gorm:"type:uuid;not null;index" json:"question_id"`
+	ObjectKey   string           `gorm:"size:500;not null" json:"object_key"`
+	FileName    string           `gorm:"size:255" json:"file_name"`
+	ContentType string           `gorm:"size:255;not null" json:"content_type"`
+	SizeBytes   int64            `json:"size_bytes"`
+	Status      FileUploadStatus `gorm:"size:20;not null;default:'pending';index" json:"status"`
+	ResponseID  string           `gorm:"size:100;index" json:"response_id,omitempty"`
+	AttachedAt  *time.Time       `json:"attached_at,omitempty"`
+	CreatedAt   time.Time        `gorm:"index" json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+
+	// Antivirus scan results
+	ScanStatus    FileScanStatus `gorm:"size:20;not null;default:'pending';index" json:"scan_status"`
+	ScanSignature string         `gorm:"size:255" json:"scan_signature,omitempty"`
+	ScannedAt     *time.Time     `json:"scanned_at,omitempty"`
+	Quarantined   bool           `gorm:"not null;default:false" json:"quarantined"`
+}
+
+// FileScanStatus represents the antivirus verdict of an upload
+type FileScanStatus string
+
+const (
+	FileScanStatusPending     FileScanStatus = "pending"
+	FileScanStatusClean       FileScanStatus = "clean"
+	FileScanStatusInfected    FileScanStatus = "infected"
+	FileScanStatusUnscannable FileScanStatus = "unscannable"
+)
+
+// BeforeCreate GORM hook called before creating a file upload
+func (f *FileUpload) BeforeCreate(tx *gorm.DB) error {
+	if f.ID == uuid.Nil {
+		f.ID = uuid.New()
+	}
+	if f.Status == "" {
+		f.Status = FileUploadStatusPending
+	}
+	if f.ScanStatus == "" {
+		f.ScanStatus = FileScanStatusPending
+	}
+	return nil
+}
+
+// TableName returns the table name for GORM
+func (FileUpload) TableName() string {
+	return "file_uploads"
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"encoding/json"
 	"fmt"
+	"net/mail"
 	"strings"
 	"time"
 
@@ -30,36 +31,203 @@ func (fs FormStatus) IsValid() bool {
 	}
 }
 
+// ResponseMode controls who may submit a form and how respondents are tracked
+type ResponseMode string
+
+const (
+	// ResponseModeAnonymous accepts submissions without login
+	ResponseModeAnonymous ResponseMode = "anonymous"
+	// ResponseModeIdentified requires login and stores the user ID with the response
+	ResponseModeIdentified ResponseMode = "identified"
+	// ResponseModeOnePerUser is identified mode limited to one response per user
+	ResponseModeOnePerUser ResponseMode = "one_per_user"
+)
+
+// IsValid validates if the response mode is valid
+func (rm ResponseMode) IsValid() bool {
+	switch rm {
+	case ResponseModeAnonymous, ResponseModeIdentified, ResponseModeOnePerUser:
+		return true
+	default:
+		return false
+	}
+}
+
+// RequiresLogin reports whether respondents must be signed in
+func (rm ResponseMode) RequiresLogin() bool {
+	return rm == ResponseModeIdentified || rm == ResponseModeOnePerUser
+}
+
 // FormSettings represents the settings of a form
 type FormSettings struct {
-	AcceptingResponses    bool   `json:"accepting_responses"`
-	RequireSignIn         bool   `json:"require_sign_in"`
-	ConfirmationMessage   string `json:"confirmation_message"`
-	AllowMultipleResponse bool   `json:"allow_multiple_response"`
-	ShowProgressBar       bool   `json:"show_progress_bar"`
-	ShuffleQuestions      bool   `json:"shuffle_questions"`
+	AcceptingResponses    bool         `json:"accepting_responses"`
+	RequireSignIn         bool         `json:"require_sign_in"`
+	ConfirmationMessage   string       `json:"confirmation_message"`
+	AllowMultipleResponse bool         `json:"allow_multiple_response"`
+	ShowProgressBar       bool         `json:"show_progress_bar"`
+	ShuffleQuestions      bool         `json:"shuffle_questions"`
+	ResponseMode          ResponseMode `json:"response_mode,omitempty"`
+	// CollectMetadata controls whether the IP address and user agent of
+	// anonymous respondents are stored. Unset means collect.
+	CollectMetadata *bool `json:"collect_metadata,omitempty"`
+	// EditWindowMinutes is how long after submitting a respondent may edit
+	// their response. Zero disables editing.
+	EditWindowMinutes int `json:"edit_window_minutes,omitempty"`
+	// Notifications controls the emails sent by the notification worker of
+	// the event bus when the form is published and when responses arrive
+	Notifications NotificationSettings `json:"notifications"`
+	// EmbedAllowedOrigins are the sites, as scheme://host[:port], allowed to
+	// embed the form and submit responses to it. Empty disables embedding.
+	EmbedAllowedOrigins []string `json:"embed_allowed_origins,omitempty"`
+	// SpamProtection is the challenge public submissions must pass. Unset
+	// means none.
+	SpamProtection SpamProtection `json:"spam_protection,omitempty"`
+	// Throttling tunes when a surge of submissions throttles the form
+	Throttling ThrottleSettings `json:"throttling"`
+}
+
+// NotificationSettings represents the email notifications of a form
+type NotificationSettings struct {
+	// NotifyOwnerOnResponse emails OwnerEmail when a response arrives
+	NotifyOwnerOnResponse bool `json:"notify_owner_on_response"`
+	// SendRespondentCopy emails respondents who gave an address a copy of
+	// their answers
+	SendRespondentCopy bool `json:"send_respondent_copy"`
+	// AttachReceipt attaches the PDF receipt of the response to the copy
+	// sent to respondents
+	AttachReceipt bool `json:"attach_receipt"`
+	// OwnerEmail is the address owner notifications are sent to
+	OwnerEmail string `json:"owner_email,omitempty"`
+	// ReplyTo is the Reply-To address of the emails sent to respondents
+	ReplyTo string `json:"reply_to,omitempty"`
 }
 
+// Validate validates the notification settings
+func (ns NotificationSettings) Validate() error {
+	if ns.OwnerEmail != "" {
+		if _, err := mail.ParseAddress(ns.OwnerEmail); err != nil {
+			return fmt.Errorf("invalid owner email: %s", ns.OwnerEmail)
+		}
+	}
+	if ns.ReplyTo != "" {
+		if _, err := mail.ParseAddress(ns.ReplyTo); err != nil {
+			return fmt.Errorf("invalid reply-to address: %s", ns.ReplyTo)
+		}
+	}
+	if ns.NotifyOwnerOnResponse && ns.OwnerEmail == "" {
+		return fmt.Errorf("owner email is required to notify the owner of responses")
+	}
+	if ns.AttachReceipt && !ns.SendRespondentCopy {
+		return fmt.Errorf("receipts are attached to the copy sent to respondents, which must be enabled")
+	}
+	return nil
+}
+
+// MaxEditWindowMinutes bounds the response edit window to 30 days
+const MaxEditWindowMinutes = 30 * 24 * 60
+
 // Validate validates the form settings
 func (fs FormSettings) Validate() error {
 	if len(fs.ConfirmationMessage) > 1000 {
 		return fmt.Errorf("confirmation message cannot exceed 1000 characters")
 	}
+	if fs.ResponseMode != "" && !fs.ResponseMode.IsValid() {
+		return fmt.Errorf("invalid response mode: %s", fs.ResponseMode)
+	}
+	if fs.EditWindowMinutes < 0 || fs.EditWindowMinutes > MaxEditWindowMinutes {
+		return fmt.Errorf("edit window must be between 0 and %d minutes", MaxEditWindowMinutes)
+	}
+	if err := fs.Notifications.Validate(); err != nil {
+		return err
+	}
+	if _, err := NormalizeOrigins(fs.EmbedAllowedOrigins); err != nil {
+		return err
+	}
+	if fs.SpamProtection != "" && !fs.SpamProtection.IsValid() {
+		return fmt.Errorf("invalid spam protection: %s", fs.SpamProtection)
+	}
+	if err := fs.Throttling.Validate(); err != nil {
+		return err
+	}
 	return nil
 }
 
+// EffectiveResponseMode returns the configured response mode. Forms created
+// before response modes existed derive it from RequireSignIn and
+// AllowMultipleResponse.
+func (fs FormSettings) EffectiveResponseMode() ResponseMode {
+	if fs.ResponseMode != "" {
+		return fs.ResponseMode
+	}
+	if !fs.RequireSignIn {
+		return ResponseModeAnonymous
+	}
+	if fs.AllowMultipleResponse {
+		return ResponseModeIdentified
+	}
+	return ResponseModeOnePerUser
+}
+
+// ResponsePolicy tells the frontend and the response service how submissions
+// to a form are accepted
+type ResponsePolicy struct {
+	Mode               ResponseMode `json:"mode"`
+	RequiresLogin      bool         `json:"requires_login"`
+	OneResponsePerUser bool         `json:"one_response_per_user"`
+	CollectMetadata    bool         `json:"collect_metadata"`
+	EditWindowMinutes  int          `json:"edit_window_minutes"`
+}
+
+// ResponsePolicy resolves the effective response policy of the settings.
+// Metadata is always collected for identified modes.
+func (fs FormSettings) ResponsePolicy() ResponsePolicy {
+	mode := fs.EffectiveResponseMode()
+	return ResponsePolicy{
+		Mode:               mode,
+		RequiresLogin:      mode.RequiresLogin(),
+		OneResponsePerUser: mode == ResponseModeOnePerUser,
+		CollectMetadata:    mode.RequiresLogin() || fs.CollectMetadata == nil || *fs.CollectMetadata,
+		EditWindowMinutes:  fs.EditWindowMinutes,
+	}
+}
+
 // Form represents a form entity
 type Form struct {
-	ID          uuid.UUID      `gorm:"type:uuid;primaryKey" json:"id"`
-	UserID      uuid.UUID      `gorm:"type:uuid;not null;index" json:"user_id"`
+	ID     uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	// OrganizationID is the organization the form belongs to
+	OrganizationID uuid.UUID `gorm:"type:uuid;not null" json:"organization_id"`
+	// Slug names the form in public URLs, unique within its organization
+	Slug        *string        `gorm:"size:63" json:"slug,omitempty"`
 	Title       string         `gorm:"size:200;not null" json:"title"`
 	Description string         `gorm:"type:text" json:"description"`
 	Status      FormStatus     `gorm:"size:20;not null;default:'draft'" json:"status"`
 	Settings    datatypes.JSON `gorm:"type:jsonb" json:"settings"`
+	// ThrottledAt is when the submissions to the form were throttled for
+	// arriving far faster than usual, nil while they are not. Saving the form
+	// never writes it; see FormRepository.SetThrottled.
+	ThrottledAt *time.Time     `gorm:"->" json:"throttled_at,omitempty"`
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
 
+	// RetentionDays is how long the responses to the form are kept before
+	// the response service purges them, nil to keep them forever
+	RetentionDays *int `json:"retention_days"`
+
+	// Internationalization: the fields above are in DefaultLocale and
+	// Translations holds a TranslationBundle per additional BCP-47 locale
+	DefaultLocale string         `gorm:"size:35;not null;default:'en'" json:"default_locale"`
+	Translations  datatypes.JSON `gorm:"type:jsonb" json:"translations,omitempty"`
+
+	// Rules are the cross-field validation rules of the form; see FormRule
+	Rules datatypes.JSON `gorm:"type:jsonb" json:"rules,omitempty" swaggertype:"array,object"`
+
+	// Version counts the changes to the definition of the form, including
+	// its questions. Builders merge their edits against the version they
+	// started from; see FormPatchOperation.
+	Version int `gorm:"not null;default:1" json:"version" example:"1"`
+
 	// Computed fields (not stored in database)
 	QuestionCount     int `gorm:"-" json:"question_count,omitempty"`
 	CollaboratorCount int `gorm:"-" json:"collaborator_count,omitempty"`
@@ -80,6 +248,10 @@ func (f *Form) BeforeCreate(tx *gorm.DB) error {
 		f.Status = FormStatusDraft
 	}
 
+	if f.DefaultLocale == "" {
+		f.DefaultLocale = DefaultLocale
+	}
+
 	return nil
 }
 
@@ -100,21 +272,58 @@ func (f *Form) Validate() error {
 	if !f.Status.IsValid() {
 		return fmt.Errorf("invalid form status: %s", f.Status)
 	}
-
-	// Validate settings if they exist
-	if len(f.Settings) > 0 {
-		var settings FormSettings
-		if err := json.Unmarshal(f.Settings, &settings); err != nil {
-			return fmt.Errorf("invalid form settings JSON: %w", err)
+	if f.Slug != nil {
+		if err := ValidateSlug(*f.Slug); err != nil {
+			return err
 		}
-		if err := settings.Validate(); err != nil {
-			return fmt.Errorf("invalid form settings: %w", err)
+	}
+	if f.RetentionDays != nil {
+		if err := ValidateRetentionDays(*f.RetentionDays); err != nil {
+			return err
 		}
 	}
+	if f.DefaultLocale != "" && !IsValidLocale(f.DefaultLocale) {
+		return fmt.Errorf("invalid default locale: %s", f.DefaultLocale)
+	}
+
+	// Validate settings if they exist
+	settings, err := f.GetSettings()
+	if err != nil {
+		return err
+	}
+	if err := settings.Validate(); err != nil {
+		return fmt.Errorf("invalid form settings: %w", err)
+	}
 
 	return nil
 }
 
+// MinRetentionDays and MaxRetentionDays bound the retention of responses
+const (
+	MinRetentionDays = 1
+	MaxRetentionDays = 3650
+)
+
+// ValidateRetentionDays checks a response retention is within bounds
+func ValidateRetentionDays(days int) error {
+	if days < MinRetentionDays || days > MaxRetentionDays {
+		return fmt.Errorf("retention must be between %d and %d days", MinRetentionDays, MaxRetentionDays)
+	}
+	return nil
+}
+
+// GetSettings decodes the form settings, returning zero settings when unset
+func (f *Form) GetSettings() (FormSettings, error) {
+	var settings FormSettings
+	if len(f.Settings) == 0 {
+		return settings, nil
+	}
+	if err := json.Unmarshal(f.Settings, &settings); err != nil {
+		return settings, fmt.Errorf("invalid form settings JSON: %w", err)
+	}
+	return settings, nil
+}
+
 // TableName returns the table name for GORM
 func (Form) TableName() string {
 	return "forms"
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Operations of a form patch
+const (
+	PatchAdd     = "add"
+	PatchRemove  = "remove"
+	PatchReplace = "replace"
+)
+
+// FormPatchOperation is a change to the definition of a form, in the style of
+// JSON Patch. Path is one of
+//
+//	/title, /description, /retention_days, /rules   replaced
+//	/settings/<key>                                 replaced
+//	/questions/<id>                                 added or removed
+//	/questions/<id>/<field>                         replaced; the field is type,
+//	                                                title, description, options,
+//	                                                validation or results_visibility
+//	/question_order                                 replaced by the question IDs
+//
+// Base is the value the editor changed, as of the version the change was
+// made on; for removes, the question as the editor last saw it. Adds have no
+// base: their value is the new question, under an ID the editor picks.
+type FormPatchOperation struct {
+	Op    string          `json:"op" binding:"required,oneof=add remove replace" enums:"add,remove,replace"`
+	Path  string          `json:"path" binding:"required" example:"/title"`
+	Value json.RawMessage `json:"value,omitempty" swaggertype:"object"`
+	Base  json.RawMessage `json:"base,omitempty" swaggertype:"object"`
+}
+
+// FormPatch is the operations a merge applied to a form, taking it from
+// PreviousVersion to Version. It is pushed to the builders open on the form,
+// which apply it rather than reading the form again.
+type FormPatch struct {
+	FormID          uuid.UUID            `json:"form_id"`
+	ActorID         uuid.UUID            `json:"actor_id"`
+	PreviousVersion int                  `json:"previous_version"`
+	Version         int                  `json:"version"`
+	Operations      []FormPatchOperation `json:"operations"`
+	CreatedAt       time.Time            `json:"created_at"`
+}
@@ -0,0 +1,320 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/expr"
+)
+
+// FormRuleType is the kind of check a cross-field rule makes
+type FormRuleType string
+
+const (
+	// FormRuleComparison compares the answers to two questions
+	FormRuleComparison FormRuleType = "comparison"
+	// FormRuleSumEquals requires the answers to number questions to add up to
+	// a value
+	FormRuleSumEquals FormRuleType = "sum_equals"
+	// FormRuleAtLeast requires at least Count of the questions to be answered
+	FormRuleAtLeast FormRuleType = "at_least"
+	// FormRuleExpression requires an expression to hold; see package expr
+	FormRuleExpression FormRuleType = "expression"
+)
+
+// MaxFormRules bounds the rules of a form
+const MaxFormRules = 50
+
+// ruleIDPattern matches rule IDs, which key the translations of messages
+var ruleIDPattern = regexp.MustCompile(`^[a-z0-9_-]{1,40}$`)
+
+// ruleKeyPattern matches the keys questions go by in a rule, which are
+// variables in expressions
+var ruleKeyPattern = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,39}$`)
+
+// FormRule is a validation rule spanning several questions of a form. The
+// rule names the questions it reads by key, and Message is shown to the
+// respondent when a response breaks it.
+type FormRule struct {
+	ID   string       `json:"id"`
+	Type FormRuleType `json:"type" enums:"comparison,sum_equals,at_least,expression"`
+	// Questions maps the keys the rule reads to question IDs
+	Questions map[string]uuid.UUID `json:"questions"`
+	// Left, Operator and Right are the keys and operator of a comparison,
+	// such as checkout > checkin
+	Left     string `json:"left,omitempty"`
+	Operator string `json:"operator,omitempty" enums:"==,!=,<,<=,>,>="`
+	Right    string `json:"right,omitempty"`
+	// Value is the total of a sum_equals rule
+	Value *float64 `json:"value,omitempty"`
+	// Count is how many questions of an at_least rule must be answered
+	Count int `json:"count,omitempty"`
+	// Expression is the condition of an expression rule
+	Expression string `json:"expression,omitempty"`
+	Message    string `json:"message"`
+}
+
+// RuleViolation is a rule a response breaks
+type RuleViolation struct {
+	RuleID      string      `json:"rule_id"`
+	QuestionIDs []uuid.UUID `json:"question_ids"`
+	Message     string      `json:"message"`
+}
+
+// ruleType is the type of the answers to questions of type t in rules
+func ruleType(t QuestionType) expr.Type {
+	switch t {
+	case QuestionTypeNumber:
+		return expr.Number
+	case QuestionTypeCheckbox, QuestionTypeFile:
+		return expr.List
+	}
+	return expr.String
+}
+
+// comparisonOperators are the operators of comparison rules
+var comparisonOperators = map[string]bool{"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true}
+
+// ValidateRules checks rules against the questions of the form: every rule
+// reads existing questions, by keys it declares, in ways their types allow.
+// Rules without an ID are given one.
+func ValidateRules(rules []FormRule, questions []*Question) error {
+	if len(rules) > MaxFormRules {
+		return fmt.Errorf("a form cannot have more than %d rules", MaxFormRules)
+	}
+	byID := make(map[uuid.UUID]*Question, len(questions))
+	for _, q := range questions {
+		byID[q.ID] = q
+	}
+
+	ids := make(map[string]bool, len(rules))
+	for i := range rules {
+		rule := &rules[i]
+		if rule.ID == "" {
+			rule.ID = fmt.Sprintf("rule_%d", i+1)
+		}
+		if !ruleIDPattern.MatchString(rule.ID) {
+			return fmt.Errorf("rule %q: IDs are 1 to 40 lowercase letters, digits, - and _", rule.ID)
+		}
+		if ids[rule.ID] {
+			return fmt.Errorf("duplicate rule %q", rule.ID)
+		}
+		ids[rule.ID] = true
+		if rule.Message == "" {
+			return fmt.Errorf("rule %q: a message is required", rule.ID)
+		}
+		if len(rule.Message) > 500 {
+			return fmt.Errorf("rule %q: the message cannot exceed 500 characters", rule.ID)
+		}
+
+		types := make(map[string]expr.Type, len(rule.Questions))
+		for key, questionID := range rule.Questions {
+			if !ruleKeyPattern.MatchString(key) || expr.Reserved(key) {
+				return fmt.Errorf("rule %q: question key %q must be a lowercase identifier", rule.ID, key)
+			}
+			q, ok := byID[questionID]
+			if !ok {
+				return fmt.Errorf("rule %q: unknown question %s", rule.ID, questionID)
+			}
+			types[key] = ruleType(q.Type)
+		}
+		if err := validateRule(rule, types); err != nil {
+			return fmt.Errorf("rule %q: %w", rule.ID, err)
+		}
+	}
+	return nil
+}
+
+func validateRule(rule *FormRule, types map[string]expr.Type) error {
+	switch rule.Type {
+	case FormRuleComparison:
+		left, ok := types[rule.Left]
+		if !ok {
+			return fmt.Errorf("unknown left question key %q", rule.Left)
+		}
+		right, ok := types[rule.Right]
+		if !ok {
+			return fmt.Errorf("unknown right question key %q", rule.Right)
+		}
+		if !comparisonOperators[rule.Operator] {
+			return fmt.Errorf("invalid operator %q", rule.Operator)
+		}
+		if left != right || left == expr.List {
+			return fmt.Errorf("can't compare a %s question with a %s question", left, right)
+		}
+	case FormRuleSumEquals:
+		if rule.Value == nil {
+			return fmt.Errorf("a value is required")
+		}
+		if len(types) < 2 {
+			return fmt.Errorf("at least 2 questions are required")
+		}
+		for key, t := range types {
+			if t != expr.Number {
+				return fmt.Errorf("question %q is not a number question", key)
+			}
+		}
+	case FormRuleAtLeast:
+		if rule.Count < 1 || rule.Count > len(types) {
+			return fmt.Errorf("count must be between 1 and the %d questions", len(types))
+		}
+	case FormRuleExpression:
+		e, err := expr.Parse(rule.Expression)
+		if err != nil {
+			return err
+		}
+		if err := e.Check(types); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid type %q", rule.Type)
+	}
+	return nil
+}
+
+// CheckRules returns the rules the answers, keyed by question ID, break.
+// Comparisons and expressions that read an unanswered question hold, as
+// whether questions are required is checked separately; sums count
+// unanswered questions as 0.
+func CheckRules(rules []FormRule, questions []*Question, answers map[string]json.RawMessage) []RuleViolation {
+	byID := make(map[uuid.UUID]*Question, len(questions))
+	for _, q := range questions {
+		byID[q.ID] = q
+	}
+
+	violations := []RuleViolation{}
+	for _, rule := range rules {
+		vars := make(map[string]expr.Value, len(rule.Questions))
+		for key, questionID := range rule.Questions {
+			t := expr.String
+			if q, ok := byID[questionID]; ok {
+				t = ruleType(q.Type)
+			}
+			vars[key] = answerValue(t, answers[questionID.String()])
+		}
+		if ruleHolds(rule, vars) {
+			continue
+		}
+
+		violation := RuleViolation{RuleID: rule.ID, Message: rule.Message}
+		for _, questionID := range rule.Questions {
+			violation.QuestionIDs = append(violation.QuestionIDs, questionID)
+		}
+		sort.Slice(violation.QuestionIDs, func(i, j int) bool {
+			return violation.QuestionIDs[i].String() < violation.QuestionIDs[j].String()
+		})
+		violations = append(violations, violation)
+	}
+	return violations
+}
+
+// sumTolerance absorbs the rounding of decimal fractions in sums
+const sumTolerance = 1e-9
+
+func ruleHolds(rule FormRule, vars map[string]expr.Value) bool {
+	var source string
+	switch rule.Type {
+	case FormRuleComparison:
+		source = rule.Left + " " + rule.Operator + " " + rule.Right
+	case FormRuleSumEquals:
+		sum := 0.0
+		for _, v := range vars {
+			if n, ok := v.Number(); ok {
+				sum += n
+			}
+		}
+		return math.Abs(sum-*rule.Value) <= sumTolerance*math.Max(1, math.Abs(*rule.Value))
+	case FormRuleAtLeast:
+		answered := 0
+		for _, v := range vars {
+			if !v.IsNull() {
+				answered++
+			}
+		}
+		return answered >= rule.Count
+	default:
+		source = rule.Expression
+	}
+
+	e, err := expr.Parse(source)
+	if err != nil {
+		// Rules are validated when saved; one that no longer parses is
+		// not held against respondents
+		return true
+	}
+	holds, err := e.Eval(vars)
+	return err != nil || holds
+}
+
+// answerValue decodes an answer to a question whose answers are of type t.
+// Missing, empty and mistyped answers are null.
+func answerValue(t expr.Type, raw json.RawMessage) expr.Value {
+	switch t {
+	case expr.Number:
+		var n json.Number
+		if json.Unmarshal(raw, &n) != nil {
+			var s string
+			if json.Unmarshal(raw, &s) != nil {
+				return expr.Null(t)
+			}
+			n = json.Number(s)
+		}
+		v, err := strconv.ParseFloat(string(n), 64)
+		if err != nil || math.IsInf(v, 0) || math.IsNaN(v) {
+			return expr.Null(t)
+		}
+		return expr.NumberValue(v)
+	case expr.List:
+		var list []string
+		if json.Unmarshal(raw, &list) != nil {
+			var s string
+			if json.Unmarshal(raw, &s) != nil || s == "" {
+				return expr.Null(t)
+			}
+			list = []string{s}
+		}
+		if len(list) == 0 {
+			return expr.Null(t)
+		}
+		return expr.ListValue(list)
+	default:
+		var s string
+		if json.Unmarshal(raw, &s) != nil || s == "" {
+			return expr.Null(t)
+		}
+		return expr.StringValue(s)
+	}
+}
+
+// GetRules decodes the cross-field rules of the form
+func (f *Form) GetRules() ([]FormRule, error) {
+	var rules []FormRule
+	if len(f.Rules) == 0 || string(f.Rules) == "null" {
+		return rules, nil
+	}
+	if err := json.Unmarshal(f.Rules, &rules); err != nil {
+		return nil, fmt.Errorf("invalid form rules JSON: %w", err)
+	}
+	return rules, nil
+}
+
+// SetRules stores the cross-field rules of the form
+func (f *Form) SetRules(rules []FormRule) error {
+	if len(rules) == 0 {
+		f.Rules = nil
+		return nil
+	}
+	data, err := json.Marshal(rules)
+	if err != nil {
+		return fmt.Errorf("failed to encode form rules: %w", err)
+	}
+	f.Rules = datatypes.JSON(data)
+	return nil
+}
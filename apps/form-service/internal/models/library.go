@@ -0,0 +1,116 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// MaxLibraryTags bounds the tags of a library question
+const MaxLibraryTags = 20
+
+// LibraryQuestion is a question of the question bank of an organization.
+// Forms copy its definition; each copy records the library question and the
+// version it was copied from. Every update of the definition bumps Version.
+type LibraryQuestion struct {
+	ID             uuid.UUID                   `gorm:"type:uuid;primaryKey" json:"id"`
+	OrganizationID uuid.UUID                   `gorm:"type:uuid;not null;index" json:"organization_id"`
+	Version        int                         `gorm:"not null;default:1" json:"version"`
+	Type           QuestionType                `gorm:"size:20;not null" json:"type"`
+	Title          string                      `gorm:"size:500;not null" json:"title"`
+	Description    string                      `gorm:"type:text" json:"description"`
+	Options        datatypes.JSON              `gorm:"type:jsonb" json:"options,omitempty"`
+	Validation     datatypes.JSON              `gorm:"type:jsonb" json:"validation"`
+	Tags           datatypes.JSONSlice[string] `gorm:"type:jsonb;not null;default:'[]'" json:"tags" swaggertype:"array,string"`
+	CreatedBy      uuid.UUID                   `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt      time.Time                   `json:"created_at"`
+	UpdatedAt      time.Time                   `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt              `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+// BeforeCreate GORM hook called before creating a library question
+func (q *LibraryQuestion) BeforeCreate(tx *gorm.DB) error {
+	if q.ID == uuid.Nil {
+		q.ID = uuid.New()
+	}
+	if q.Version == 0 {
+		q.Version = 1
+	}
+	return nil
+}
+
+// BeforeSave GORM hook validates the library question whenever it is saved
+func (q *LibraryQuestion) BeforeSave(tx *gorm.DB) error {
+	return q.Validate()
+}
+
+// Validate validates the definition the way the questions of forms are
+// validated, so that copies of it are valid, and normalizes the tags
+func (q *LibraryQuestion) Validate() error {
+	question := q.Copy(uuid.Nil, 0)
+	if err := question.Validate(); err != nil {
+		return err
+	}
+	q.Title, q.Description = question.Title, question.Description
+
+	tags := make(datatypes.JSONSlice[string], 0, len(q.Tags))
+	seen := make(map[string]bool, len(q.Tags))
+	for _, tag := range q.Tags {
+		tag = NormalizeTag(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > 50 {
+			return fmt.Errorf("tag %q cannot exceed 50 characters", tag)
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	if len(tags) > MaxLibraryTags {
+		return fmt.Errorf("a library question has at most %d tags", MaxLibraryTags)
+	}
+	q.Tags = tags
+	return nil
+}
+
+// Copy returns the question of the form formID at order copying the
+// definition, with its provenance
+func (q *LibraryQuestion) Copy(formID uuid.UUID, order int) *Question {
+	id, version := q.ID, q.Version
+	return &Question{
+		FormID:            formID,
+		Type:              q.Type,
+		Title:             q.Title,
+		Description:       q.Description,
+		Order:             order,
+		Options:           q.Options,
+		Validation:        q.Validation,
+		LibraryQuestionID: &id,
+		LibraryVersion:    &version,
+	}
+}
+
+// NormalizeTag trims and lowercases a tag, so that tags match regardless of
+// case
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// TableName returns the table name for GORM
+func (LibraryQuestion) TableName() string {
+	return "library_questions"
+}
+
+// LibraryQuestionUsage is a question of a form copied from an older version
+// of a library question
+type LibraryQuestionUsage struct {
+	FormID         uuid.UUID  `json:"form_id"`
+	FormTitle      string     `json:"form_title"`
+	FormStatus     FormStatus `json:"form_status" enums:"draft,published,closed"`
+	QuestionID     uuid.UUID  `json:"question_id"`
+	LibraryVersion int        `json:"library_version"`
+}
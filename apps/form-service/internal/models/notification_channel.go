@@ -0,0 +1,156 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// ChannelType is the chat service a notification channel posts to
+type ChannelType string
+
+const (
+	ChannelTypeSlack ChannelType = "slack"
+	ChannelTypeTeams ChannelType = "teams"
+)
+
+// IsValid validates if the channel type is valid
+func (ct ChannelType) IsValid() bool {
+	return ct == ChannelTypeSlack || ct == ChannelTypeTeams
+}
+
+// webhookHosts are the hosts the webhooks of each channel type are served
+// from; a leading dot matches subdomains. Webhooks are called by the event
+// bus, so they must not reach anything else.
+var webhookHosts = map[ChannelType][]string{
+	ChannelTypeSlack: {"hooks.slack.com"},
+	ChannelTypeTeams: {".webhook.office.com", "outlook.office.com", ".logic.azure.com"},
+}
+
+// Form events a notification channel can be notified of
+const (
+	ChannelEventResponseCreated  = "form.response.created"
+	ChannelEventFormPublished    = "form.published"
+	ChannelEventThrottleEngaged  = "form.throttle.engaged"
+	ChannelEventThrottleReleased = "form.throttle.released"
+)
+
+// ChannelEventTypes lists the events notification channels can be notified of
+var ChannelEventTypes = []string{
+	ChannelEventResponseCreated,
+	ChannelEventFormPublished,
+	ChannelEventThrottleEngaged,
+	ChannelEventThrottleReleased,
+}
+
+// MaxChannelsPerForm bounds the notification channels of a form
+const MaxChannelsPerForm = 10
+
+// NotificationChannel posts the events of a form to a Slack or Microsoft
+// Teams channel through an incoming webhook. The webhook URL is a
+// credential: it is stored sealed and only its hint is shown.
+type NotificationChannel struct {
+	ID     uuid.UUID   `gorm:"type:uuid;primaryKey" json:"id"`
+	FormID uuid.UUID   `gorm:"type:uuid;not null;index" json:"form_id"`
+	Type   ChannelType `gorm:"size:10;not null" json:"type"`
+	Name   string      `gorm:"size:100;not null" json:"name"`
+	// SealedWebhookURL is the webhook URL sealed with the channel secret
+	SealedWebhookURL string `gorm:"column:webhook_url;type:text;not null" json:"-"`
+	// WebhookHint tells webhooks apart without revealing them
+	WebhookHint string                      `gorm:"size:100;not null" json:"webhook_hint"`
+	EventTypes  datatypes.JSONSlice[string] `gorm:"type:jsonb;not null" json:"event_types" swaggertype:"array,string"`
+	// QuietHoursStart and QuietHoursEnd bound, as HH:MM in Timezone, the
+	// time of day nothing is posted; events then are not posted later.
+	// The end may be past midnight. Empty disables quiet hours.
+	QuietHoursStart string `gorm:"size:5;not null;default:''" json:"quiet_hours_start,omitempty"`
+	QuietHoursEnd   string `gorm:"size:5;not null;default:''" json:"quiet_hours_end,omitempty"`
+	Timezone        string `gorm:"size:64;not null" json:"timezone"`
+	// IncludeAnswerSummary posts the answers of responses; otherwise
+	// messages never show answer values
+	IncludeAnswerSummary bool      `gorm:"not null;default:false" json:"include_answer_summary"`
+	Enabled              bool      `gorm:"not null;default:false" json:"enabled"`
+	CreatedBy            uuid.UUID `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for GORM
+func (NotificationChannel) TableName() string {
+	return "notification_channels"
+}
+
+// Validate validates the notification channel, but not its webhook URL,
+// which is validated by ValidateWebhookURL before it is sealed
+func (c *NotificationChannel) Validate() error {
+	if !c.Type.IsValid() {
+		return fmt.Errorf("invalid channel type: %s", c.Type)
+	}
+	if strings.TrimSpace(c.Name) == "" || len(c.Name) > 100 {
+		return fmt.Errorf("channel name is required and cannot exceed 100 characters")
+	}
+	if len(c.EventTypes) == 0 {
+		return fmt.Errorf("a channel must be notified of at least one event type")
+	}
+	for _, eventType := range c.EventTypes {
+		if !isChannelEventType(eventType) {
+			return fmt.Errorf("invalid event type: %s", eventType)
+		}
+	}
+	if (c.QuietHoursStart == "") != (c.QuietHoursEnd == "") {
+		return fmt.Errorf("quiet hours need both a start and an end")
+	}
+	for _, clock := range []string{c.QuietHoursStart, c.QuietHoursEnd} {
+		if _, _, ok := parseClock(clock); clock != "" && !ok {
+			return fmt.Errorf("quiet hours must be times of day as HH:MM: %s", clock)
+		}
+	}
+	if c.QuietHoursStart != "" && c.QuietHoursStart == c.QuietHoursEnd {
+		return fmt.Errorf("quiet hours cannot start and end at the same time")
+	}
+	if _, err := time.LoadLocation(c.Timezone); err != nil || c.Timezone == "" {
+		return fmt.Errorf("invalid timezone: %s", c.Timezone)
+	}
+	return nil
+}
+
+func isChannelEventType(eventType string) bool {
+	for _, known := range ChannelEventTypes {
+		if eventType == known {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateWebhookURL checks that rawURL is an HTTPS webhook of the chat
+// service of channelType
+func ValidateWebhookURL(channelType ChannelType, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || u.User != nil || u.Port() != "" || u.Path == "" || u.Path == "/" {
+		return fmt.Errorf("the webhook URL must be an https URL without credentials or port")
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range webhookHosts[channelType] {
+		if host == allowed || (strings.HasPrefix(allowed, ".") && strings.HasSuffix(host, allowed)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("the webhook URL is not a %s webhook: %s", channelType, host)
+}
+
+// WebhookHint shows the host and the last characters of a webhook URL
+func WebhookHint(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	if len(path) > 4 {
+		path = path[len(path)-4:]
+	}
+	return u.Hostname() + "/…" + path
+}
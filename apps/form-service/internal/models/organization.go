@@ -0,0 +1,107 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OrganizationRole represents the role of a member in an organization
+type OrganizationRole string
+
+const (
+	// OrganizationRoleMember creates forms in the organization
+	OrganizationRoleMember OrganizationRole = "member"
+	// OrganizationRoleAdmin can also invite members
+	OrganizationRoleAdmin OrganizationRole = "admin"
+	// OrganizationRoleOwner is the role of the creator of the organization.
+	// It is never granted to an invited member.
+	OrganizationRoleOwner OrganizationRole = "owner"
+)
+
+// IsValid reports whether the role can be granted to an invited member
+func (r OrganizationRole) IsValid() bool {
+	return r == OrganizationRoleMember || r == OrganizationRoleAdmin
+}
+
+// CanInvite reports whether members of the role may invite members
+func (r OrganizationRole) CanInvite() bool {
+	return r == OrganizationRoleOwner || r == OrganizationRoleAdmin
+}
+
+// CanManageLibrary reports whether members of the role may propagate
+// library questions into the draft forms of other members
+func (r OrganizationRole) CanManageLibrary() bool {
+	return r == OrganizationRoleOwner || r == OrganizationRoleAdmin
+}
+
+// CanViewUsage reports whether members of the role may see the usage of the
+// organization against its plan
+func (r OrganizationRole) CanViewUsage() bool {
+	return r == OrganizationRoleOwner || r == OrganizationRoleAdmin
+}
+
+// CanTransferForms reports whether members of the role may transfer the
+// forms of other members, and accept the forms transferred to the
+// organization
+func (r OrganizationRole) CanTransferForms() bool {
+	return r == OrganizationRoleOwner || r == OrganizationRoleAdmin
+}
+
+// CanOverrideDeletionCap reports whether members of the role may delete
+// more of the responses of a form at once than the cap allows
+func (r OrganizationRole) CanOverrideDeletionCap() bool {
+	return r == OrganizationRoleOwner || r == OrganizationRoleAdmin
+}
+
+// PersonalOrganizationName is the name of the organization every user gets
+// for the forms they create outside of any other organization
+const PersonalOrganizationName = "Personal"
+
+// Organization is a workspace that owns forms. Users act in one
+// organization at a time and only see its forms.
+type Organization struct {
+	ID   uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Name string    `gorm:"size:200;not null" json:"name"`
+	// Personal organizations are created for each user and hold the forms
+	// created before organizations existed
+	Personal bool `gorm:"not null;default:false" json:"personal"`
+	// Plan names the plan whose quotas apply to the organization
+	Plan      string    `gorm:"size:50;not null;default:'free'" json:"plan"`
+	CreatedBy uuid.UUID `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// BeforeCreate hook is called before creating an organization
+func (o *Organization) BeforeCreate(tx *gorm.DB) error {
+	if o.ID == uuid.Nil {
+		o.ID = uuid.New()
+	}
+	return nil
+}
+
+// Validate checks the organization before it is created
+func (o *Organization) Validate() error {
+	name := strings.TrimSpace(o.Name)
+	if name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(name) > 200 {
+		return fmt.Errorf("name must be at most 200 characters")
+	}
+	return nil
+}
+
+// OrganizationMember is a user's membership of an organization
+type OrganizationMember struct {
+	OrganizationID uuid.UUID        `gorm:"type:uuid;primaryKey" json:"organization_id"`
+	UserID         uuid.UUID        `gorm:"type:uuid;primaryKey;index" json:"user_id"`
+	Email          string           `gorm:"size:320" json:"email,omitempty"`
+	Role           OrganizationRole `gorm:"size:20;not null" json:"role"`
+	InvitedBy      *uuid.UUID       `gorm:"type:uuid" json:"invited_by,omitempty"`
+	JoinedAt       time.Time        `gorm:"not null" json:"joined_at"`
+}
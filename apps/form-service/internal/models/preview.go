@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DefaultPreviewTokenTTL is how long preview tokens stay valid unless
+// issued for less
+const DefaultPreviewTokenTTL = 7 * 24 * time.Hour
+
+// PreviewToken records a token sharing the draft of a form, so that it can
+// be listed and revoked. The token itself is only returned when it is
+// issued.
+type PreviewToken struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	FormID    uuid.UUID `gorm:"type:uuid;not null;index" json:"form_id"`
+	CreatedBy uuid.UUID `gorm:"type:uuid;not null" json:"created_by"`
+	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
+	// RevokedAt is when the token was revoked, nil while it is not
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// BeforeCreate GORM hook called before creating a preview token
+func (t *PreviewToken) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// Active reports whether the token grants a preview at now
+func (t *PreviewToken) Active(now time.Time) bool {
+	return t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
+
+// TableName returns the table name for GORM
+func (PreviewToken) TableName() string {
+	return "form_preview_tokens"
+}
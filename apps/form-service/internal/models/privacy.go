@@ -0,0 +1,23 @@
+package models
+
+import "github.com/google/uuid"
+
+// ErasurePolicy is what the erasure of a user does to the forms they own
+type ErasurePolicy string
+
+const (
+	// ErasurePolicyDelete deletes the forms with their questions, uploads
+	// and reports
+	ErasurePolicyDelete ErasurePolicy = "delete"
+	// ErasurePolicyAnonymize keeps the forms collecting responses, but
+	// hands them to ErasedOwnerID and drops the owner's addresses from them
+	ErasurePolicyAnonymize ErasurePolicy = "anonymize"
+)
+
+// IsValid validates if the erasure policy is valid
+func (ep ErasurePolicy) IsValid() bool {
+	return ep == ErasurePolicyDelete || ep == ErasurePolicyAnonymize
+}
+
+// ErasedOwnerID owns the forms of erased users kept by the anonymize policy
+var ErasedOwnerID = uuid.Nil
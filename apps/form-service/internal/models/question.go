@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -21,6 +22,30 @@ const (
 	QuestionTypeSelect   QuestionType = "select"
 	QuestionTypeRadio    QuestionType = "radio"
 	QuestionTypeCheckbox QuestionType = "checkbox"
+	QuestionTypeFile     QuestionType = "file"
+)
+
+// Aggregatable reports whether the answers to questions of the type are
+// drawn from a fixed set of options, so that their distribution reveals no
+// free-form answer
+func (t QuestionType) Aggregatable() bool {
+	switch t {
+	case QuestionTypeSelect, QuestionTypeRadio, QuestionTypeCheckbox:
+		return true
+	}
+	return false
+}
+
+// ResultsVisibility is who may read the results of a question
+type ResultsVisibility string
+
+const (
+	// ResultsVisibilityPrivate results are only read by the editors of the
+	// form
+	ResultsVisibilityPrivate ResultsVisibility = "private"
+	// ResultsVisibilityAggregatePublic results are published as an answer
+	// distribution on the public results of the form
+	ResultsVisibilityAggregatePublic ResultsVisibility = "aggregate_public"
 )
 
 // Question represents a question entity
@@ -33,9 +58,23 @@ type Question struct {
 	Order       int            `gorm:"not null" json:"order"`
 	Options     datatypes.JSON `gorm:"type:jsonb" json:"options,omitempty"`
 	Validation  datatypes.JSON `gorm:"type:jsonb" json:"validation"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+	// Key is the stable name of the question, unique within the form, which
+	// responses are projected under and exports name their columns after.
+	// It is generated from the title unless given, and only changes when
+	// renamed.
+	Key string `gorm:"size:64;not null;default:''" json:"key" example:"age_group"`
+	// Display holds the display settings of choice questions
+	Display datatypes.JSON `gorm:"type:jsonb" json:"display,omitempty" swaggertype:"object"`
+	// ResultsVisibility is private unless the distribution of the answers is
+	// published. Only questions of aggregatable types may be published.
+	ResultsVisibility ResultsVisibility `gorm:"size:20;not null;default:private" json:"results_visibility" enums:"private,aggregate_public"`
+	// LibraryQuestionID and LibraryVersion are the library question the
+	// question was copied from and its version, if any
+	LibraryQuestionID *uuid.UUID     `gorm:"type:uuid;index" json:"library_question_id,omitempty"`
+	LibraryVersion    *int           `json:"library_version,omitempty"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
 
 	// Relationships
 	Form *Form `gorm:"foreignKey:FormID" json:"form,omitempty"`
@@ -67,10 +106,71 @@ func (q *Question) Validate() error {
 	if q.Order < 0 {
 		return fmt.Errorf("question order must be non-negative")
 	}
+	if q.Key != "" {
+		if err := ValidateQuestionKey(q.Key); err != nil {
+			return err
+		}
+	}
+	if q.ResultsVisibility == "" {
+		q.ResultsVisibility = ResultsVisibilityPrivate
+	}
+	if q.ResultsVisibility == ResultsVisibilityAggregatePublic && !q.Type.Aggregatable() {
+		return fmt.Errorf("only select, radio and checkbox questions can have aggregate_public results")
+	}
+	display, err := q.GetDisplay()
+	if err != nil {
+		return err
+	}
+	if err := display.Validate(q.Type); err != nil {
+		return err
+	}
+	if q.Type == QuestionTypeFile {
+		constraints, err := q.GetFileConstraints()
+		if err != nil {
+			return err
+		}
+		if err := constraints.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// QuestionDisplay are the display settings of a choice question. Randomized
+// options are shuffled by clients with the shuffle seed of the respondent, so
+// each respondent sees the same order on every load; answers and results
+// stay keyed by option key.
+type QuestionDisplay struct {
+	RandomizeOptions bool `json:"randomize_options,omitempty"`
+	// PinLastOption keeps the last option, such as "Other", in place when
+	// the others are shuffled
+	PinLastOption bool `json:"pin_last_option,omitempty"`
+}
 
+// Validate checks the settings apply to questions of type t
+func (d QuestionDisplay) Validate(t QuestionType) error {
+	if d.RandomizeOptions && !t.Aggregatable() {
+		return fmt.Errorf("only select, radio and checkbox questions can randomize their options")
+	}
+	if d.PinLastOption && !d.RandomizeOptions {
+		return fmt.Errorf("pin_last_option requires randomize_options")
+	}
 	return nil
 }
 
+// GetDisplay decodes the display settings of the question
+func (q *Question) GetDisplay() (QuestionDisplay, error) {
+	var display QuestionDisplay
+	if len(q.Display) == 0 || string(q.Display) == "null" {
+		return display, nil
+	}
+	if err := json.Unmarshal(q.Display, &display); err != nil {
+		return display, fmt.Errorf("invalid display settings for question %s: %w", q.ID, err)
+	}
+	return display, nil
+}
+
 // TableName returns the table name for GORM
 func (Question) TableName() string {
 	return "questions"
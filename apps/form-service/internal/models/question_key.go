@@ -0,0 +1,109 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Question keys name questions in analytics and exports, e.g. age_group.
+// They are unique within a form, and stay with the question when its title
+// changes.
+const (
+	MaxQuestionKeyLength = 64
+
+	// defaultQuestionKey is the key generated for questions whose title has
+	// no letter nor digit
+	defaultQuestionKey = "question"
+)
+
+// questionKeyPattern allows lowercase letters, digits and underscores,
+// starting with a letter
+var questionKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// NormalizeQuestionKey trims and lowercases a question key
+func NormalizeQuestionKey(key string) string {
+	return strings.ToLower(strings.TrimSpace(key))
+}
+
+// ValidateQuestionKey checks a normalized question key is well formed
+func ValidateQuestionKey(key string) error {
+	if key == "" || len(key) > MaxQuestionKeyLength {
+		return fmt.Errorf("question key must be between 1 and %d characters", MaxQuestionKeyLength)
+	}
+	if !questionKeyPattern.MatchString(key) {
+		return fmt.Errorf("question key may only contain lowercase letters, digits and underscores, starting with a letter")
+	}
+	return nil
+}
+
+// QuestionKeyFromText derives a question key from the text of a question:
+// its letters and digits, accents stripped, with an underscore for each run
+// of anything else. The key leaves room for the suffix UniqueQuestionKey
+// may append.
+func QuestionKeyFromText(text string) string {
+	var b strings.Builder
+	underscore := false
+	for _, r := range norm.NFD.String(strings.ToLower(text)) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if underscore && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			underscore = false
+			b.WriteRune(r)
+		case unicode.Is(unicode.Mn, r):
+			// Accents decomposed from their letter
+		default:
+			underscore = true
+		}
+		if b.Len() >= MaxQuestionKeyLength-8 {
+			break
+		}
+	}
+
+	key := strings.TrimRight(b.String(), "_")
+	if key == "" {
+		return defaultQuestionKey
+	}
+	if key[0] >= '0' && key[0] <= '9' {
+		key = "q_" + key
+	}
+	return key
+}
+
+// UniqueQuestionKey returns key, or key suffixed with the first number from
+// 2 that makes it unique, when taken has it. The key returned is added to
+// taken.
+func UniqueQuestionKey(key string, taken map[string]bool) string {
+	unique := key
+	for n := 2; taken[unique]; n++ {
+		suffix := "_" + strconv.Itoa(n)
+		unique = key[:min(len(key), MaxQuestionKeyLength-len(suffix))] + suffix
+	}
+	taken[unique] = true
+	return unique
+}
+
+// QuestionKeyAlias is a key a question was renamed from. The answers
+// projected under the key keep being aggregated with those of the question,
+// and no other question of the form may take it.
+type QuestionKeyAlias struct {
+	FormID     uuid.UUID `gorm:"type:uuid;primaryKey" json:"form_id"`
+	Key        string    `gorm:"size:64;primaryKey" json:"key"`
+	QuestionID uuid.UUID `gorm:"type:uuid;not null;index" json:"question_id"`
+	// RenamedBy is the editor who renamed the key
+	RenamedBy uuid.UUID `gorm:"type:uuid;not null" json:"renamed_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for GORM
+func (QuestionKeyAlias) TableName() string {
+	return "question_key_aliases"
+}
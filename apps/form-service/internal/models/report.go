@@ -0,0 +1,209 @@
+package models
+
+import (
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// ReportFrequency is how often a scheduled report is generated
+type ReportFrequency string
+
+const (
+	ReportFrequencyDaily  ReportFrequency = "daily"
+	ReportFrequencyWeekly ReportFrequency = "weekly"
+)
+
+// IsValid validates if the report frequency is valid
+func (rf ReportFrequency) IsValid() bool {
+	return rf == ReportFrequencyDaily || rf == ReportFrequencyWeekly
+}
+
+// ReportFormat is the file format of a generated report
+type ReportFormat string
+
+const (
+	ReportFormatHTML ReportFormat = "html"
+	ReportFormatCSV  ReportFormat = "csv"
+)
+
+// IsValid validates if the report format is valid
+func (rf ReportFormat) IsValid() bool {
+	return rf == ReportFormatHTML || rf == ReportFormatCSV
+}
+
+// ContentType returns the MIME type of reports in the format
+func (rf ReportFormat) ContentType() string {
+	if rf == ReportFormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "text/html; charset=utf-8"
+}
+
+// MaxReportRecipients bounds the recipients of a scheduled report
+const MaxReportRecipients = 10
+
+// ReportSchedule enrolls a form in periodic summary reports. Runs happen at
+// SendAt wall-clock time in Timezone, so they keep their local time across
+// daylight saving changes.
+type ReportSchedule struct {
+	FormID    uuid.UUID       `gorm:"type:uuid;primaryKey" json:"form_id"`
+	Frequency ReportFrequency `gorm:"size:20;not null" json:"frequency"`
+	// Weekday is the day weekly reports run on, 0 being Sunday
+	Weekday int `gorm:"not null;default:0" json:"weekday"`
+	// SendAt is the local time of day reports run at, as HH:MM
+	SendAt     string                      `gorm:"size:5;not null" json:"send_at"`
+	Timezone   string                      `gorm:"size:64;not null" json:"timezone"`
+	Recipients datatypes.JSONSlice[string] `gorm:"type:jsonb;not null" json:"recipients" swaggertype:"array,string"`
+	Format     ReportFormat                `gorm:"size:10;not null" json:"format"`
+	// IncludeEmpty also reports periods without responses, which are
+	// skipped otherwise
+	IncludeEmpty bool      `gorm:"not null;default:false" json:"include_empty"`
+	NextRunAt    time.Time `gorm:"not null;index" json:"next_run_at"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for GORM
+func (ReportSchedule) TableName() string {
+	return "report_schedules"
+}
+
+// Validate validates the report schedule
+func (s *ReportSchedule) Validate() error {
+	if !s.Frequency.IsValid() {
+		return fmt.Errorf("invalid report frequency: %s", s.Frequency)
+	}
+	if s.Weekday < 0 || s.Weekday > 6 {
+		return fmt.Errorf("weekday must be between 0 (Sunday) and 6")
+	}
+	if _, _, err := s.clock(); err != nil {
+		return err
+	}
+	if _, err := time.LoadLocation(s.Timezone); err != nil || s.Timezone == "" {
+		return fmt.Errorf("invalid timezone: %s", s.Timezone)
+	}
+	if !s.Format.IsValid() {
+		return fmt.Errorf("invalid report format: %s", s.Format)
+	}
+	if len(s.Recipients) > MaxReportRecipients {
+		return fmt.Errorf("a report cannot have more than %d recipients", MaxReportRecipients)
+	}
+	for _, recipient := range s.Recipients {
+		if _, err := mail.ParseAddress(recipient); err != nil {
+			return fmt.Errorf("invalid recipient: %s", recipient)
+		}
+	}
+	return nil
+}
+
+// clock parses SendAt into an hour and minute
+func (s *ReportSchedule) clock() (hour, minute int, err error) {
+	if hour, minute, ok := parseClock(s.SendAt); ok {
+		return hour, minute, nil
+	}
+	return 0, 0, fmt.Errorf("send_at must be a time of day as HH:MM: %s", s.SendAt)
+}
+
+// parseClock parses a time of day as HH:MM
+func parseClock(value string) (hour, minute int, ok bool) {
+	h, m, ok := strings.Cut(value, ":")
+	if ok && len(h) == 2 && len(m) == 2 {
+		hour, herr := strconv.Atoi(h)
+		minute, merr := strconv.Atoi(m)
+		if herr == nil && merr == nil && hour >= 0 && hour < 24 && minute >= 0 && minute < 60 {
+			return hour, minute, true
+		}
+	}
+	return 0, 0, false
+}
+
+// NextRun returns the first run of the schedule strictly after t. Days are
+// stepped in local calendar time, so a run at 09:00 stays at 09:00 on both
+// sides of a daylight saving change. A time skipped by a spring-forward
+// transition runs at the equivalent instant later that day.
+func (s *ReportSchedule) NextRun(t time.Time) (time.Time, error) {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timezone: %s", s.Timezone)
+	}
+	hour, minute, err := s.clock()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	local := t.In(loc)
+	for day := 0; day <= 8; day++ {
+		run := time.Date(local.Year(), local.Month(), local.Day()+day, hour, minute, 0, 0, loc)
+		if run.Hour() != hour || run.Minute() != minute {
+			// The time was skipped by a spring-forward transition, and
+			// time.Date normalized it to before the transition by the
+			// length of the gap
+			_, before := run.Zone()
+			_, after := run.Add(24 * time.Hour).Zone()
+			run = run.Add(time.Duration(after-before) * time.Second)
+		}
+		if !run.After(t) {
+			continue
+		}
+		if s.Frequency == ReportFrequencyWeekly && run.Weekday() != time.Weekday(s.Weekday) {
+			continue
+		}
+		return run.UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("no run of the %s schedule follows %s", s.Frequency, t)
+}
+
+// Period returns the period a run at runAt reports on: the day or week of
+// local calendar time ending at the run
+func (s *ReportSchedule) Period(runAt time.Time) (start, end time.Time) {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	days := 1
+	if s.Frequency == ReportFrequencyWeekly {
+		days = 7
+	}
+
+	local := runAt.In(loc)
+	start = time.Date(local.Year(), local.Month(), local.Day()-days, local.Hour(), local.Minute(), 0, 0, loc)
+	return start.UTC(), runAt.UTC()
+}
+
+// Report is a generated report, stored as a downloadable artifact
+type Report struct {
+	ID          uuid.UUID    `gorm:"type:uuid;primaryKey" json:"id"`
+	FormID      uuid.UUID    `gorm:"type:uuid;not null;uniqueIndex:idx_reports_form_period" json:"form_id"`
+	PeriodStart time.Time    `gorm:"not null" json:"period_start"`
+	PeriodEnd   time.Time    `gorm:"not null;uniqueIndex:idx_reports_form_period" json:"period_end"`
+	Format      ReportFormat `gorm:"size:10;not null" json:"format"`
+	ObjectKey   string       `gorm:"size:500;not null" json:"-"`
+	SizeBytes   int64        `json:"size_bytes"`
+	// Responses is the number of responses of the period
+	Responses      int       `gorm:"not null" json:"responses"`
+	CompletionRate float64   `json:"completion_rate"`
+	CreatedAt      time.Time `json:"created_at"`
+
+	// DownloadURL is a short-lived link to the artifact, set when listing
+	DownloadURL string `gorm:"-" json:"download_url,omitempty"`
+}
+
+// BeforeCreate GORM hook called before creating a report
+func (r *Report) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName returns the table name for GORM
+func (Report) TableName() string {
+	return "reports"
+}
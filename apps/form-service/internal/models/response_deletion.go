@@ -0,0 +1,134 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// MaxDeletionResponseIDs bounds the response IDs a bulk deletion lists
+const MaxDeletionResponseIDs = 1000
+
+// ResponseFilter selects the responses of a form a bulk deletion deletes.
+// A response matches when it matches every filter set; at least one must be
+// set.
+type ResponseFilter struct {
+	// SubmittedBefore and SubmittedAfter select the responses first
+	// submitted before and after them
+	SubmittedBefore *time.Time `json:"submitted_before,omitempty" example:"2024-03-01T00:00:00Z"`
+	SubmittedAfter  *time.Time `json:"submitted_after,omitempty" example:"2024-01-01T00:00:00Z"`
+	ResponseIDs     []string   `json:"response_ids,omitempty"`
+	// TestOnly selects the responses flagged as test submissions
+	TestOnly bool `json:"test_only,omitempty"`
+}
+
+// Validate validates the filter of a bulk deletion
+func (f ResponseFilter) Validate() error {
+	if f.SubmittedBefore == nil && f.SubmittedAfter == nil && len(f.ResponseIDs) == 0 && !f.TestOnly {
+		return fmt.Errorf("a bulk deletion needs submitted_before, submitted_after, response_ids or test_only")
+	}
+	if f.SubmittedBefore != nil && f.SubmittedAfter != nil && !f.SubmittedAfter.Before(*f.SubmittedBefore) {
+		return fmt.Errorf("submitted_after must be before submitted_before")
+	}
+	if len(f.ResponseIDs) > MaxDeletionResponseIDs {
+		return fmt.Errorf("at most %d response IDs can be deleted at once", MaxDeletionResponseIDs)
+	}
+	for _, id := range f.ResponseIDs {
+		if id == "" {
+			return fmt.Errorf("response IDs must not be empty")
+		}
+	}
+	return nil
+}
+
+// Hash returns the SHA-256 of the filter, the same for filters listing the
+// same response IDs in another order
+func (f ResponseFilter) Hash() string {
+	normalized := f
+	normalized.ResponseIDs = append([]string(nil), f.ResponseIDs...)
+	sort.Strings(normalized.ResponseIDs)
+	if normalized.SubmittedBefore != nil {
+		before := normalized.SubmittedBefore.UTC()
+		normalized.SubmittedBefore = &before
+	}
+	if normalized.SubmittedAfter != nil {
+		after := normalized.SubmittedAfter.UTC()
+		normalized.SubmittedAfter = &after
+	}
+	data, _ := json.Marshal(normalized)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// DeletedResponse is a response a bulk deletion deletes, with what its
+// deletion releases: the usage it was metered in the month it was
+// submitted, unless it was a test submission
+type DeletedResponse struct {
+	ID          string    `json:"id"`
+	SubmittedAt time.Time `json:"submitted_at"`
+	Test        bool      `json:"test,omitempty"`
+}
+
+// ResponseDeletionStatus is the state of a bulk deletion job
+type ResponseDeletionStatus string
+
+const (
+	ResponseDeletionQueued    ResponseDeletionStatus = "queued"
+	ResponseDeletionRunning   ResponseDeletionStatus = "running"
+	ResponseDeletionCompleted ResponseDeletionStatus = "completed"
+	// ResponseDeletionFailed jobs stopped on an error and can be resumed
+	ResponseDeletionFailed ResponseDeletionStatus = "failed"
+)
+
+// ResponseDeletionJob deletes the responses of a form matching its filter
+// in batches, no more than its dry run counted. The batch being deleted is
+// saved before it is deleted, so a job resumed after failing or taken over
+// from a replica that stopped finishes it first.
+type ResponseDeletionJob struct {
+	ID             uuid.UUID                          `gorm:"type:uuid;primaryKey" json:"id"`
+	FormID         uuid.UUID                          `gorm:"type:uuid;not null;index" json:"form_id"`
+	OrganizationID uuid.UUID                          `gorm:"type:uuid;not null" json:"organization_id"`
+	Status         ResponseDeletionStatus             `gorm:"size:20;not null;index" json:"status"`
+	Filter         datatypes.JSONType[ResponseFilter] `gorm:"type:jsonb;not null" json:"filter" swaggertype:"object"`
+	// ConfirmationID is the ID of the confirmation token the deletion was
+	// started with; a token starts a single deletion
+	ConfirmationID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex" json:"-"`
+	RequestedBy    uuid.UUID `gorm:"type:uuid;not null" json:"requested_by"`
+	// CapOverridden is set when an admin of the organization let the
+	// deletion past the share of the responses of a form that can be
+	// deleted at once
+	CapOverridden bool `gorm:"not null;default:false" json:"cap_overridden"`
+	// Matched is the number of responses the dry run counted, the most the
+	// job deletes, and Total the responses of the form then
+	Matched int `gorm:"not null" json:"matched"`
+	Total   int `gorm:"not null" json:"total"`
+	Deleted int `gorm:"not null;default:0" json:"deleted"`
+	// Pending is the batch being deleted
+	Pending datatypes.JSONType[[]DeletedResponse] `gorm:"type:jsonb" json:"-"`
+
+	Error      string     `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// BeforeCreate GORM hook called before creating a bulk deletion job
+func (j *ResponseDeletionJob) BeforeCreate(tx *gorm.DB) error {
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName returns the table name for GORM
+func (ResponseDeletionJob) TableName() string {
+	return "response_deletion_jobs"
+}
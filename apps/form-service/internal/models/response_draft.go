@@ -0,0 +1,63 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// Draft limits
+const (
+	// MaxDraftSizeBytes is the largest answers blob accepted for a draft
+	MaxDraftSizeBytes = 256 * 1024
+	// DefaultDraftTTL is how long a draft is kept after its last save
+	DefaultDraftTTL = 7 * 24 * time.Hour
+)
+
+// DraftOwner identifies whose draft is addressed: a logged-in user or an
+// anonymous respondent holding a client-generated respondent token
+type DraftOwner struct {
+	UserID          string
+	RespondentToken string
+}
+
+// Key returns the storage key of the owner. Users take precedence over
+// respondent tokens, and tokens are stored hashed. It is empty when the owner
+// is unidentified.
+func (o DraftOwner) Key() string {
+	if o.UserID != "" {
+		return "user:" + o.UserID
+	}
+	if o.RespondentToken != "" {
+		sum := sha256.Sum256([]byte(o.RespondentToken))
+		return "token:" + hex.EncodeToString(sum[:])
+	}
+	return ""
+}
+
+// ResponseDraft is a partially completed response autosaved for resume.
+// Drafts live in Redis; drafts of logged-in users are written through to
+// PostgreSQL so they survive Redis eviction. Drafts are not responses and
+// never count toward response totals.
+type ResponseDraft struct {
+	FormID    uuid.UUID      `gorm:"type:uuid;primaryKey" json:"form_id"`
+	OwnerKey  string         `gorm:"size:100;primaryKey" json:"-"`
+	UserID    string         `gorm:"size:255;index" json:"user_id,omitempty"`
+	Answers   datatypes.JSON `gorm:"type:jsonb;not null" json:"answers"`
+	SizeBytes int            `gorm:"not null" json:"size_bytes"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	ExpiresAt time.Time      `gorm:"not null;index" json:"expires_at"`
+}
+
+// IsExpired reports whether the draft has outlived its TTL at now
+func (d *ResponseDraft) IsExpired(now time.Time) bool {
+	return !d.ExpiresAt.After(now)
+}
+
+// TableName returns the table name for GORM
+func (ResponseDraft) TableName() string {
+	return "response_drafts"
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Slugs name published forms in URLs, e.g. forms.acme.com/feedback. They
+// are unique within an organization.
+const (
+	MinSlugLength = 3
+	MaxSlugLength = 63
+
+	// SlugRedirectTTL is how long a replaced slug keeps redirecting to the
+	// form that had it
+	SlugRedirectTTL = 30 * 24 * time.Hour
+)
+
+// slugPattern allows lowercase letters and digits, with single hyphens
+// between them
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// reservedSlugs are path segments the frontend and the gateway route
+// themselves
+var reservedSlugs = map[string]bool{
+	"admin": true, "api": true, "app": true, "assets": true, "auth": true,
+	"by-slug": true, "dashboard": true, "docs": true, "edit": true, "forms": true,
+	"health": true, "help": true, "login": true, "logout": true, "metrics": true,
+	"new": true, "public": true, "settings": true, "signup": true, "static": true,
+	"swagger": true, "www": true,
+}
+
+// NormalizeSlug trims and lowercases a slug
+func NormalizeSlug(slug string) string {
+	return strings.ToLower(strings.TrimSpace(slug))
+}
+
+// ValidateSlug checks a normalized slug is URL safe and not reserved
+func ValidateSlug(slug string) error {
+	if len(slug) < MinSlugLength || len(slug) > MaxSlugLength {
+		return fmt.Errorf("slug must be between %d and %d characters", MinSlugLength, MaxSlugLength)
+	}
+	if !slugPattern.MatchString(slug) {
+		return fmt.Errorf("slug may only contain lowercase letters, digits and single hyphens between them")
+	}
+	if reservedSlugs[slug] {
+		return fmt.Errorf("slug %q is reserved", slug)
+	}
+	return nil
+}
+
+// FormSlugRedirect keeps a slug a form no longer has pointing at it until
+// ExpiresAt, so links shared with the old slug keep working
+type FormSlugRedirect struct {
+	OrganizationID uuid.UUID `gorm:"type:uuid;primaryKey" json:"organization_id"`
+	Slug           string    `gorm:"size:63;primaryKey" json:"slug"`
+	FormID         uuid.UUID `gorm:"type:uuid;not null;index" json:"form_id"`
+	ExpiresAt      time.Time `gorm:"not null" json:"expires_at"`
+	CreatedAt      time.Time `json:"created_at"`
+}
@@ -0,0 +1,59 @@
+package models
+
+// SpamProtection is the challenge public submissions to a form must pass
+type SpamProtection string
+
+const (
+	// SpamProtectionNone accepts submissions without a challenge
+	SpamProtectionNone SpamProtection = "none"
+	// SpamProtectionCaptcha requires a token from the CAPTCHA provider of
+	// the service
+	SpamProtectionCaptcha SpamProtection = "captcha"
+	// SpamProtectionHoneypot requires a hidden field to be left empty and
+	// the form to be filled in no faster than a person would
+	SpamProtectionHoneypot SpamProtection = "honeypot"
+)
+
+// IsValid checks if the spam protection is valid
+func (sp SpamProtection) IsValid() bool {
+	switch sp {
+	case SpamProtectionNone, SpamProtectionCaptcha, SpamProtectionHoneypot:
+		return true
+	default:
+		return false
+	}
+}
+
+// CaptchaProvider is a CAPTCHA service submissions are verified with
+type CaptchaProvider string
+
+const (
+	CaptchaProviderRecaptcha CaptchaProvider = "recaptcha"
+	CaptchaProviderHCaptcha  CaptchaProvider = "hcaptcha"
+	CaptchaProviderTurnstile CaptchaProvider = "turnstile"
+)
+
+// IsValid checks if the CAPTCHA provider is valid
+func (cp CaptchaProvider) IsValid() bool {
+	switch cp {
+	case CaptchaProviderRecaptcha, CaptchaProviderHCaptcha, CaptchaProviderTurnstile:
+		return true
+	default:
+		return false
+	}
+}
+
+// SpamChallenge tells respondents how to pass the spam protection of a form
+type SpamChallenge struct {
+	Mode SpamProtection `json:"mode" enums:"captcha,honeypot"`
+	// Provider and SiteKey render the CAPTCHA widget whose token is
+	// submitted as captchaToken
+	Provider CaptchaProvider `json:"provider,omitempty" enums:"recaptcha,hcaptcha,turnstile"`
+	SiteKey  string          `json:"site_key,omitempty"`
+	// HoneypotField is the hidden field submitted as honeypot, which must be
+	// left empty
+	HoneypotField string `json:"honeypot_field,omitempty" example:"website"`
+	// Token is the signed issue time of the definition, submitted as
+	// challengeToken
+	Token string `json:"token,omitempty"`
+}
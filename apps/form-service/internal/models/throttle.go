@@ -0,0 +1,50 @@
+package models
+
+import "fmt"
+
+// Defaults of the throttle settings of forms
+const (
+	DefaultThrottleMultiplier       = 100
+	DefaultThrottleMinRatePerMinute = 30
+	DefaultThrottleIPLimitPerMinute = 3
+)
+
+// ThrottleSettings tune when the anomaly detector of the event bus throttles
+// a form: once submissions arrive Multiplier times faster than the baseline
+// of the last 24 hours, and at least MinRatePerMinute. Throttled forms
+// require the CAPTCHA challenge and accept IPLimitPerMinute submissions per
+// IP address until the rate normalizes. Zero values take the defaults.
+type ThrottleSettings struct {
+	Disabled         bool    `json:"disabled,omitempty"`
+	Multiplier       float64 `json:"multiplier,omitempty" example:"100"`
+	MinRatePerMinute float64 `json:"min_rate_per_minute,omitempty" example:"30"`
+	IPLimitPerMinute int     `json:"ip_limit_per_minute,omitempty" example:"3"`
+}
+
+// Validate validates the throttle settings
+func (ts ThrottleSettings) Validate() error {
+	if ts.Multiplier != 0 && (ts.Multiplier < 2 || ts.Multiplier > 10000) {
+		return fmt.Errorf("throttle multiplier must be between 2 and 10000")
+	}
+	if ts.MinRatePerMinute < 0 {
+		return fmt.Errorf("throttle minimum rate cannot be negative")
+	}
+	if ts.IPLimitPerMinute < 0 {
+		return fmt.Errorf("throttle IP limit cannot be negative")
+	}
+	return nil
+}
+
+// Effective returns the settings with the defaults filled in
+func (ts ThrottleSettings) Effective() ThrottleSettings {
+	if ts.Multiplier == 0 {
+		ts.Multiplier = DefaultThrottleMultiplier
+	}
+	if ts.MinRatePerMinute == 0 {
+		ts.MinRatePerMinute = DefaultThrottleMinRatePerMinute
+	}
+	if ts.IPLimitPerMinute == 0 {
+		ts.IPLimitPerMinute = DefaultThrottleIPLimitPerMinute
+	}
+	return ts
+}
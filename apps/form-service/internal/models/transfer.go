@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TransferStatus is the state of a form transfer
+type TransferStatus string
+
+const (
+	// TransferStatusPending waits for the target to accept the transfer
+	TransferStatusPending TransferStatus = "pending"
+	// TransferStatusAccepted moved the form to the target
+	TransferStatusAccepted TransferStatus = "accepted"
+	// TransferStatusCancelled was cancelled by the source or declined by
+	// the target
+	TransferStatusCancelled TransferStatus = "cancelled"
+	// TransferStatusExpired was not accepted within TransferTTL
+	TransferStatusExpired TransferStatus = "expired"
+)
+
+// IsValid reports whether the status is known
+func (s TransferStatus) IsValid() bool {
+	switch s {
+	case TransferStatusPending, TransferStatusAccepted, TransferStatusCancelled, TransferStatusExpired:
+		return true
+	default:
+		return false
+	}
+}
+
+// TransferTTL is how long the target of a transfer has to accept it
+const TransferTTL = 7 * 24 * time.Hour
+
+// FormTransfer moves a form to another owner or organization once the
+// target accepts it. A transfer to a user makes them the owner; one to an
+// organization moves the form into it, owned by the admin who accepts.
+type FormTransfer struct {
+	ID     uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	FormID uuid.UUID `gorm:"type:uuid;not null" json:"form_id"`
+	// FormTitle is the title of the form when the transfer was requested,
+	// for the target who can't see the form yet
+	FormTitle          string    `gorm:"size:200;not null" json:"form_title"`
+	FromUserID         uuid.UUID `gorm:"type:uuid;not null" json:"from_user_id"`
+	FromOrganizationID uuid.UUID `gorm:"type:uuid;not null" json:"from_organization_id"`
+	// Exactly one of ToUserID and ToOrganizationID is set
+	ToUserID         *uuid.UUID     `gorm:"type:uuid" json:"to_user_id,omitempty" swaggertype:"string" format:"uuid"`
+	ToOrganizationID *uuid.UUID     `gorm:"type:uuid" json:"to_organization_id,omitempty" swaggertype:"string" format:"uuid"`
+	Status           TransferStatus `gorm:"size:20;not null" json:"status"`
+	RequestedBy      uuid.UUID      `gorm:"type:uuid;not null" json:"requested_by"`
+	ExpiresAt        time.Time      `gorm:"not null" json:"expires_at"`
+	// ResolvedBy is the user who accepted or cancelled the transfer
+	ResolvedBy *uuid.UUID `gorm:"type:uuid" json:"resolved_by,omitempty" swaggertype:"string" format:"uuid"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// BeforeCreate hook is called before creating a transfer
+func (t *FormTransfer) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// Expired reports whether a pending transfer can no longer be accepted at now
+func (t *FormTransfer) Expired(now time.Time) bool {
+	return t.Status == TransferStatusPending && !now.Before(t.ExpiresAt)
+}
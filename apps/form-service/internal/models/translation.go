@@ -0,0 +1,335 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gorm.io/datatypes"
+)
+
+// DefaultLocale is the locale assumed for forms that don't declare one
+const DefaultLocale = "en"
+
+// localePattern matches BCP-47 language tags such as "de", "pt-BR" or "zh-Hant-TW"
+var localePattern = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z]{4})?(-([a-zA-Z]{2}|[0-9]{3}))?$`)
+
+// IsValidLocale reports whether locale is a supported BCP-47 language tag
+func IsValidLocale(locale string) bool {
+	return localePattern.MatchString(locale)
+}
+
+// NormalizeLocale canonicalizes the casing of a BCP-47 tag ("pt-br" -> "pt-BR")
+func NormalizeLocale(locale string) string {
+	parts := strings.Split(strings.TrimSpace(locale), "-")
+	for i, part := range parts {
+		switch {
+		case i == 0:
+			parts[i] = strings.ToLower(part)
+		case len(part) == 4:
+			parts[i] = strings.ToUpper(part[:1]) + strings.ToLower(part[1:])
+		default:
+			parts[i] = strings.ToUpper(part)
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// QuestionOption represents a single selectable option of a choice question
+type QuestionOption struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+}
+
+// QuestionTranslation holds the translated strings of a single question
+type QuestionTranslation struct {
+	Title              string            `json:"title,omitempty"`
+	Description        string            `json:"description,omitempty"`
+	Options            map[string]string `json:"options,omitempty"`
+	ValidationMessages map[string]string `json:"validation_messages,omitempty"`
+}
+
+// TranslationBundle holds every translated string of a form for one locale.
+// Questions are keyed by question ID, and the messages of rules by rule ID.
+type TranslationBundle struct {
+	Title       string                         `json:"title,omitempty"`
+	Description string                         `json:"description,omitempty"`
+	Questions   map[string]QuestionTranslation `json:"questions,omitempty"`
+	Rules       map[string]string              `json:"rules,omitempty"`
+}
+
+// Validate checks the bundle against the form's questions. Translated option
+// lists must carry exactly the option keys of the default locale.
+func (b TranslationBundle) Validate(questions []*Question) error {
+	byID := make(map[string]*Question, len(questions))
+	for _, q := range questions {
+		byID[q.ID.String()] = q
+	}
+
+	for questionID, qt := range b.Questions {
+		q, ok := byID[questionID]
+		if !ok {
+			return fmt.Errorf("translation references unknown question %s", questionID)
+		}
+		if len(qt.Options) == 0 {
+			continue
+		}
+
+		options, err := q.GetOptions()
+		if err != nil {
+			return err
+		}
+		if len(options) != len(qt.Options) {
+			return fmt.Errorf("question %s: translated options must match the default option keys", questionID)
+		}
+		for _, opt := range options {
+			if _, ok := qt.Options[opt.Key]; !ok {
+				return fmt.Errorf("question %s: missing translation for option key %q", questionID, opt.Key)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidateRules checks that the bundle only translates the messages of rules
+// of the form
+func (b TranslationBundle) ValidateRules(rules []FormRule) error {
+	ids := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		ids[rule.ID] = true
+	}
+	for id := range b.Rules {
+		if !ids[id] {
+			return fmt.Errorf("translation references unknown rule %s", id)
+		}
+	}
+	return nil
+}
+
+// MissingStrings lists the strings of the default locale that have no
+// translation in the bundle
+func (b TranslationBundle) MissingStrings(form *Form, questions []*Question) []string {
+	var missing []string
+
+	if b.Title == "" && form.Title != "" {
+		missing = append(missing, "title")
+	}
+	if b.Description == "" && form.Description != "" {
+		missing = append(missing, "description")
+	}
+
+	for _, q := range questions {
+		qt := b.Questions[q.ID.String()]
+		if qt.Title == "" && q.Title != "" {
+			missing = append(missing, fmt.Sprintf("questions.%s.title", q.ID))
+		}
+		if qt.Description == "" && q.Description != "" {
+			missing = append(missing, fmt.Sprintf("questions.%s.description", q.ID))
+		}
+		options, _ := q.GetOptions()
+		for _, opt := range options {
+			if qt.Options[opt.Key] == "" {
+				missing = append(missing, fmt.Sprintf("questions.%s.options.%s", q.ID, opt.Key))
+			}
+		}
+	}
+
+	rules, _ := form.GetRules()
+	for _, rule := range rules {
+		if b.Rules[rule.ID] == "" {
+			missing = append(missing, fmt.Sprintf("rules.%s", rule.ID))
+		}
+	}
+
+	return missing
+}
+
+// GetTranslations decodes the form's translation bundles keyed by locale
+func (f *Form) GetTranslations() (map[string]TranslationBundle, error) {
+	translations := make(map[string]TranslationBundle)
+	if len(f.Translations) == 0 {
+		return translations, nil
+	}
+	if err := json.Unmarshal(f.Translations, &translations); err != nil {
+		return nil, fmt.Errorf("invalid form translations JSON: %w", err)
+	}
+	return translations, nil
+}
+
+// SetTranslation stores the bundle for locale, replacing any existing one
+func (f *Form) SetTranslation(locale string, bundle TranslationBundle) error {
+	translations, err := f.GetTranslations()
+	if err != nil {
+		return err
+	}
+	translations[locale] = bundle
+
+	data, err := json.Marshal(translations)
+	if err != nil {
+		return fmt.Errorf("failed to encode form translations: %w", err)
+	}
+	f.Translations = datatypes.JSON(data)
+	return nil
+}
+
+// GetDefaultLocale returns the form's default locale
+func (f *Form) GetDefaultLocale() string {
+	if f.DefaultLocale == "" {
+		return DefaultLocale
+	}
+	return f.DefaultLocale
+}
+
+// Locales returns the sorted list of locales the form has translations for
+func (f *Form) Locales() []string {
+	translations, err := f.GetTranslations()
+	if err != nil {
+		return nil
+	}
+	locales := make([]string, 0, len(translations))
+	for locale := range translations {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// GetOptions decodes the question's option list
+func (q *Question) GetOptions() ([]QuestionOption, error) {
+	var options []QuestionOption
+	if len(q.Options) == 0 || string(q.Options) == "null" {
+		return options, nil
+	}
+	if err := json.Unmarshal(q.Options, &options); err != nil {
+		return nil, fmt.Errorf("invalid options JSON for question %s: %w", q.ID, err)
+	}
+	return options, nil
+}
+
+// ResolveLocale picks the bundle to use for the requested locale. It tries the
+// exact tag, then its base language ("de-AT" -> "de"). The second return value
+// is the locale actually used; it is the form's default locale when nothing
+// matches.
+func ResolveLocale(translations map[string]TranslationBundle, requested, defaultLocale string) (TranslationBundle, string) {
+	requested = NormalizeLocale(requested)
+	if bundle, ok := translations[requested]; ok {
+		return bundle, requested
+	}
+	if base, _, found := strings.Cut(requested, "-"); found {
+		if bundle, ok := translations[base]; ok {
+			return bundle, base
+		}
+	}
+	return TranslationBundle{}, defaultLocale
+}
+
+// Localize returns copies of the form and its questions with every string
+// replaced by its translation in bundle. Missing strings fall back to the
+// default locale.
+func Localize(form *Form, questions []*Question, bundle TranslationBundle) (*Form, []*Question) {
+	localizedForm := *form
+	localizedForm.Translations = nil
+	if bundle.Title != "" {
+		localizedForm.Title = bundle.Title
+	}
+	if bundle.Description != "" {
+		localizedForm.Description = bundle.Description
+	}
+	localizedForm.Rules = localizeRules(form, bundle.Rules)
+
+	localizedQuestions := make([]*Question, 0, len(questions))
+	for _, q := range questions {
+		lq := *q
+		qt, ok := bundle.Questions[q.ID.String()]
+		if ok {
+			if qt.Title != "" {
+				lq.Title = qt.Title
+			}
+			if qt.Description != "" {
+				lq.Description = qt.Description
+			}
+			lq.Options = localizeOptions(q, qt.Options)
+			lq.Validation = localizeValidation(q.Validation, qt.ValidationMessages)
+		}
+		localizedQuestions = append(localizedQuestions, &lq)
+	}
+
+	return &localizedForm, localizedQuestions
+}
+
+// localizeRules replaces the messages of the rules of a form
+func localizeRules(form *Form, messages map[string]string) datatypes.JSON {
+	if len(messages) == 0 {
+		return form.Rules
+	}
+	rules, err := form.GetRules()
+	if err != nil {
+		return form.Rules
+	}
+	for i, rule := range rules {
+		if message := messages[rule.ID]; message != "" {
+			rules[i].Message = message
+		}
+	}
+	data, err := json.Marshal(rules)
+	if err != nil {
+		return form.Rules
+	}
+	return datatypes.JSON(data)
+}
+
+// localizeOptions replaces option labels, keeping keys and order intact
+func localizeOptions(q *Question, labels map[string]string) datatypes.JSON {
+	if len(labels) == 0 {
+		return q.Options
+	}
+	options, err := q.GetOptions()
+	if err != nil {
+		return q.Options
+	}
+	for i, opt := range options {
+		if label := labels[opt.Key]; label != "" {
+			options[i].Label = label
+		}
+	}
+	data, err := json.Marshal(options)
+	if err != nil {
+		return q.Options
+	}
+	return datatypes.JSON(data)
+}
+
+// localizeValidation overlays translated messages onto the "messages" object
+// of a question's validation rules
+func localizeValidation(validation datatypes.JSON, messages map[string]string) datatypes.JSON {
+	if len(messages) == 0 {
+		return validation
+	}
+	rules := make(map[string]interface{})
+	if len(validation) > 0 {
+		if err := json.Unmarshal(validation, &rules); err != nil || rules == nil {
+			return validation
+		}
+	}
+	merged := make(map[string]interface{})
+	if existing, ok := rules["messages"].(map[string]interface{}); ok {
+		for k, v := range existing {
+			merged[k] = v
+		}
+	}
+	for k, v := range messages {
+		if v != "" {
+			merged[k] = v
+		}
+	}
+	rules["messages"] = merged
+
+	data, err := json.Marshal(rules)
+	if err != nil {
+		return validation
+	}
+	return datatypes.JSON(data)
+}
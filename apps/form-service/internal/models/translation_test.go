@@ -0,0 +1,202 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+func TestResolveLocale(t *testing.T) {
+	translations := map[string]TranslationBundle{
+		"de":    {Title: "Umfrage"},
+		"pt-BR": {Title: "Pesquisa"},
+		"zh":    {Title: "调查"},
+	}
+
+	tests := []struct {
+		name      string
+		requested string
+		wantTitle string
+		wantUsed  string
+	}{
+		{"exact tag", "pt-BR", "Pesquisa", "pt-BR"},
+		{"exact tag in other casing", "pt-br", "Pesquisa", "pt-BR"},
+		{"base language of a region", "de-AT", "Umfrage", "de"},
+		{"base language of a script and region", "zh-Hant-TW", "调查", "zh"},
+		{"base language without its region", "pt", "", "en"},
+		{"unknown language", "fr", "", "en"},
+		{"unknown region of an unknown language", "fr-CA", "", "en"},
+		{"nothing requested", "", "", "en"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, used := ResolveLocale(translations, tt.requested, "en")
+			if bundle.Title != tt.wantTitle || used != tt.wantUsed {
+				t.Errorf("ResolveLocale(%q) = %q in %q, want %q in %q", tt.requested, bundle.Title, used, tt.wantTitle, tt.wantUsed)
+			}
+		})
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	form := &Form{
+		Title:        "Survey",
+		Description:  "About you",
+		Translations: datatypes.JSON(`{"de":{"title":"Umfrage"}}`),
+		Rules:        datatypes.JSON(`[{"id":"contact","type":"at_least","questions":{},"count":1,"message":"Leave a way to reach you"}]`),
+	}
+	question := &Question{
+		ID:         uuid.New(),
+		Title:      "Colour",
+		Options:    datatypes.JSON(`[{"key":"r","label":"Red"},{"key":"g","label":"Green"}]`),
+		Validation: datatypes.JSON(`{"required":true,"messages":{"required":"Pick one","pattern":"Letters only"}}`),
+	}
+	other := &Question{ID: uuid.New(), Title: "Name"}
+
+	tests := []struct {
+		name            string
+		bundle          TranslationBundle
+		wantTitle       string
+		wantDescription string
+		wantRule        string
+		wantQuestion    string
+		wantLabels      string
+		wantMessages    map[string]string
+	}{
+		{
+			name:            "empty bundle",
+			wantTitle:       "Survey",
+			wantDescription: "About you",
+			wantRule:        "Leave a way to reach you",
+			wantQuestion:    "Colour",
+			wantLabels:      "r=Red g=Green",
+			wantMessages:    map[string]string{"required": "Pick one", "pattern": "Letters only"},
+		},
+		{
+			name: "complete bundle",
+			bundle: TranslationBundle{
+				Title:       "Umfrage",
+				Description: "Über Sie",
+				Rules:       map[string]string{"contact": "Hinterlassen Sie einen Kontakt"},
+				Questions: map[string]QuestionTranslation{question.ID.String(): {
+					Title:              "Farbe",
+					Options:            map[string]string{"r": "Rot", "g": "Grün"},
+					ValidationMessages: map[string]string{"required": "Wählen Sie eine", "pattern": "Nur Buchstaben"},
+				}},
+			},
+			wantTitle:       "Umfrage",
+			wantDescription: "Über Sie",
+			wantRule:        "Hinterlassen Sie einen Kontakt",
+			wantQuestion:    "Farbe",
+			wantLabels:      "r=Rot g=Grün",
+			wantMessages:    map[string]string{"required": "Wählen Sie eine", "pattern": "Nur Buchstaben"},
+		},
+		{
+			name: "missing strings fall back",
+			bundle: TranslationBundle{
+				Title: "Umfrage",
+				Questions: map[string]QuestionTranslation{question.ID.String(): {
+					Options:            map[string]string{"g": "Grün"},
+					ValidationMessages: map[string]string{"required": "Wählen Sie eine", "pattern": ""},
+				}},
+			},
+			wantTitle:       "Umfrage",
+			wantDescription: "About you",
+			wantRule:        "Leave a way to reach you",
+			wantQuestion:    "Colour",
+			wantLabels:      "r=Red g=Grün",
+			wantMessages:    map[string]string{"required": "Wählen Sie eine", "pattern": "Letters only"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			localized, questions := Localize(form, []*Question{question, other}, tt.bundle)
+			if localized.Title != tt.wantTitle || localized.Description != tt.wantDescription {
+				t.Errorf("form = %q, %q, want %q, %q", localized.Title, localized.Description, tt.wantTitle, tt.wantDescription)
+			}
+			if localized.Translations != nil {
+				t.Errorf("localized form keeps its translations")
+			}
+			rules, err := localized.GetRules()
+			if err != nil || len(rules) != 1 || rules[0].Message != tt.wantRule {
+				t.Errorf("rules = %+v (err %v), want the message %q", rules, err, tt.wantRule)
+			}
+
+			if len(questions) != 2 || questions[1].Title != "Name" {
+				t.Fatalf("questions = %+v, want both questions in order", questions)
+			}
+			localizedQuestion := questions[0]
+			if localizedQuestion.Title != tt.wantQuestion {
+				t.Errorf("question title = %q, want %q", localizedQuestion.Title, tt.wantQuestion)
+			}
+			options, err := localizedQuestion.GetOptions()
+			if err != nil {
+				t.Fatal(err)
+			}
+			labels := make([]string, len(options))
+			for i, option := range options {
+				labels[i] = option.Key + "=" + option.Label
+			}
+			if got := strings.Join(labels, " "); got != tt.wantLabels {
+				t.Errorf("options = %s, want %s", got, tt.wantLabels)
+			}
+			var validation struct {
+				Required bool              `json:"required"`
+				Messages map[string]string `json:"messages"`
+			}
+			if err := json.Unmarshal(localizedQuestion.Validation, &validation); err != nil {
+				t.Fatal(err)
+			}
+			if !validation.Required || len(validation.Messages) != len(tt.wantMessages) {
+				t.Errorf("validation = %+v, want the rules kept with messages %v", validation, tt.wantMessages)
+			}
+			for key, message := range tt.wantMessages {
+				if validation.Messages[key] != message {
+					t.Errorf("message %s = %q, want %q", key, validation.Messages[key], message)
+				}
+			}
+		})
+	}
+
+	if form.Title != "Survey" || question.Title != "Colour" || !strings.Contains(string(question.Options), "Red") {
+		t.Errorf("Localize changed the form or its questions")
+	}
+}
+
+func TestTranslationBundleValidate(t *testing.T) {
+	choice := &Question{ID: uuid.New(), Options: datatypes.JSON(`[{"key":"r","label":"Red"},{"key":"g","label":"Green"}]`)}
+	text := &Question{ID: uuid.New()}
+	questions := []*Question{choice, text}
+	translate := func(q *Question, options map[string]string) TranslationBundle {
+		return TranslationBundle{Questions: map[string]QuestionTranslation{q.ID.String(): {Title: "t", Options: options}}}
+	}
+
+	tests := []struct {
+		name    string
+		bundle  TranslationBundle
+		wantErr string
+	}{
+		{"every option key", translate(choice, map[string]string{"r": "Rot", "g": "Grün"}), ""},
+		{"no options", translate(choice, nil), ""},
+		{"question without options", translate(text, nil), ""},
+		{"missing option key", translate(choice, map[string]string{"r": "Rot"}), "translated options must match the default option keys"},
+		{"extra option key", translate(choice, map[string]string{"r": "Rot", "g": "Grün", "b": "Blau"}), "translated options must match the default option keys"},
+		{"renamed option key", translate(choice, map[string]string{"r": "Rot", "green": "Grün"}), `missing translation for option key "g"`},
+		{"options of a question without any", translate(text, map[string]string{"r": "Rot"}), "translated options must match the default option keys"},
+		{"unknown question", translate(&Question{ID: uuid.New()}, nil), "translation references unknown question"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.bundle.Validate(questions)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Validate() = %v, want nil", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Validate() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UsageMetric is a quantity metered per organization per month for billing
+type UsageMetric string
+
+const (
+	// UsageFormsCreated counts the forms created, deleted ones included
+	UsageFormsCreated UsageMetric = "forms_created"
+	// UsageResponses counts the responses collected
+	UsageResponses UsageMetric = "responses"
+	// UsageStorageBytes sums the size of the files uploaded to answers
+	UsageStorageBytes UsageMetric = "storage_bytes"
+	// UsageExports counts the response export jobs run
+	UsageExports UsageMetric = "exports"
+)
+
+// UsageMetrics lists every metered quantity
+var UsageMetrics = []UsageMetric{UsageFormsCreated, UsageResponses, UsageStorageBytes, UsageExports}
+
+// UsageEvent is an occurrence of a metered quantity. ID identifies what
+// happened, such as the form created, so an event replayed is counted once.
+type UsageEvent struct {
+	ID             string
+	OrganizationID uuid.UUID
+	Metric         UsageMetric
+	Quantity       int64
+	At             time.Time
+}
+
+// UsageMonth returns the month t is metered in, as YYYY-MM in UTC
+func UsageMonth(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
+
+// ParseUsageMonth returns the first instant of a YYYY-MM month
+func ParseUsageMonth(month string) (time.Time, error) {
+	start, err := time.Parse("2006-01", month)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("month must be YYYY-MM")
+	}
+	return start, nil
+}
+
+// OrganizationUsage is what an organization used in a month. The meter
+// flushes its counters into it; reconciliation corrects it from the source
+// of truth.
+type OrganizationUsage struct {
+	OrganizationID uuid.UUID `gorm:"type:uuid;primaryKey" json:"organization_id"`
+	Month          string    `gorm:"size:7;primaryKey" json:"month"`
+	FormsCreated   int64     `gorm:"not null;default:0" json:"forms_created"`
+	Responses      int64     `gorm:"not null;default:0" json:"responses"`
+	StorageBytes   int64     `gorm:"not null;default:0" json:"storage_bytes"`
+	Exports        int64     `gorm:"not null;default:0" json:"exports"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	// ReconciledAt is when the usage was last checked against the source of
+	// truth
+	ReconciledAt *time.Time `json:"reconciled_at,omitempty"`
+}
+
+// TableName names the table of organization usage
+func (OrganizationUsage) TableName() string {
+	return "organization_usage"
+}
+
+// Get returns the quantity of metric
+func (u *OrganizationUsage) Get(metric UsageMetric) int64 {
+	switch metric {
+	case UsageFormsCreated:
+		return u.FormsCreated
+	case UsageResponses:
+		return u.Responses
+	case UsageStorageBytes:
+		return u.StorageBytes
+	case UsageExports:
+		return u.Exports
+	}
+	return 0
+}
+
+// Set sets the quantity of metric
+func (u *OrganizationUsage) Set(metric UsageMetric, quantity int64) {
+	switch metric {
+	case UsageFormsCreated:
+		u.FormsCreated = quantity
+	case UsageResponses:
+		u.Responses = quantity
+	case UsageStorageBytes:
+		u.StorageBytes = quantity
+	case UsageExports:
+		u.Exports = quantity
+	}
+}
+
+// DefaultPlan is the plan of organizations that were given none
+const DefaultPlan = "free"
+
+// Plan holds the quotas of the organizations on it. A zero quota is
+// unlimited.
+type Plan struct {
+	Name string `json:"name"`
+	// MaxActiveForms bounds the forms of the organization not closed
+	MaxActiveForms int64 `json:"max_active_forms"`
+	// MaxResponsesPerMonth bounds the responses collected in a month
+	MaxResponsesPerMonth int64 `json:"max_responses_per_month"`
+}
@@ -0,0 +1,55 @@
+// Package preview signs the tokens that share draft forms with people
+// without accounts, with signedtoken. Tokens are also recorded by the form
+// service, so that they can be revoked before they expire.
+package preview
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/signedtoken"
+)
+
+// Scope is the scope of preview tokens, which grant nothing but reading the
+// draft of a form
+const Scope = "preview"
+
+// Config holds the secret preview tokens are signed with and how long they
+// may be valid
+type Config struct {
+	Secret string
+	MaxTTL time.Duration
+}
+
+// ErrInvalidToken is returned for tokens that are malformed, not signed with
+// the secret, not preview tokens or expired
+var ErrInvalidToken = errors.New("invalid preview token")
+
+// Claims are the claims of a preview token
+type Claims struct {
+	// TokenID is the ID the token is recorded with
+	TokenID uuid.UUID `json:"jti"`
+	FormID  uuid.UUID `json:"form_id"`
+	// Version is the last update of the form when the token was issued, in
+	// Unix milliseconds
+	Version   int64  `json:"ver"`
+	Scope     string `json:"scope"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Sign returns the token of claims signed with secret
+func Sign(secret string, claims Claims) (string, error) {
+	return signedtoken.Sign(secret, claims)
+}
+
+// Verify returns the claims of a token signed with secret, which must be a
+// preview token that has not expired at now
+func Verify(secret, token string, now time.Time) (Claims, error) {
+	claims, err := signedtoken.Verify[Claims](secret, token)
+	if err != nil || claims.Scope != Scope || claims.FormID == uuid.Nil || now.Unix() >= claims.ExpiresAt {
+		return Claims{}, ErrInvalidToken
+	}
+	return claims, nil
+}
@@ -0,0 +1,52 @@
+package preview
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSignVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	claims := Claims{
+		TokenID:   uuid.MustParse("0b9f1c2e-3d4a-4b5c-8d6e-7f8091a2b3c4"),
+		FormID:    uuid.MustParse("6f1c2d3e-4a5b-4c6d-8e7f-901a2b3c4d5e"),
+		Version:   now.Add(-time.Hour).UnixMilli(),
+		Scope:     Scope,
+		ExpiresAt: now.Add(time.Hour).Unix(),
+	}
+
+	token, err := Sign("secret", claims)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	got, err := Verify("secret", token, now)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if got != claims {
+		t.Errorf("claims = %+v, want %+v", got, claims)
+	}
+
+	other := claims
+	other.Scope = "edit"
+	otherScope, _ := Sign("secret", other)
+	tampered := token[:len(token)-2] + "xx"
+
+	for name, tc := range map[string]struct {
+		secret, token string
+		now           time.Time
+	}{
+		"other secret": {"other", token, now},
+		"expired":      {"secret", token, now.Add(time.Hour)},
+		"tampered":     {"secret", tampered, now},
+		"malformed":    {"secret", "not-a-token", now},
+		"other scope":  {"secret", otherScope, now},
+	} {
+		if _, err := Verify(tc.secret, tc.token, tc.now); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: error = %v, want ErrInvalidToken", name, err)
+		}
+	}
+}
@@ -0,0 +1,324 @@
+// Package receipt renders the PDF receipts respondents keep of their
+// responses: the title of the form, the questions and the answers of the
+// response, when it was submitted and a verification hash of its content.
+//
+// Text is set in a Unicode TrueType font. Right-to-left paragraphs, such as
+// Hebrew and Arabic answers, are wrapped in logical order then reordered
+// for display line by line and aligned right. Arabic letters are not
+// shaped: they appear in their isolated forms, in the right order.
+package receipt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-pdf/fpdf"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/text/unicode/bidi"
+)
+
+// ContentType is the MIME type of receipts
+const ContentType = "application/pdf"
+
+// Page layout, in millimetres and points
+const (
+	pageMargin     = 20.0
+	footerHeight   = 12.0
+	titleSize      = 16.0
+	textSize       = 11.0
+	smallSize      = 8.0
+	lineHeight     = 5.5
+	smallLine      = 4.0
+	answerSpacing  = 4.0
+	unansweredText = "—"
+)
+
+// fontCandidates are where distributions install DejaVu Sans, which covers
+// Latin, Greek, Cyrillic, Hebrew and Arabic. The Go fonts, covering the
+// first three, are used when none is installed.
+var fontCandidates = [][2]string{
+	{"/usr/share/fonts/dejavu/DejaVuSans.ttf", "/usr/share/fonts/dejavu/DejaVuSans-Bold.ttf"},
+	{"/usr/share/fonts/ttf-dejavu/DejaVuSans.ttf", "/usr/share/fonts/ttf-dejavu/DejaVuSans-Bold.ttf"},
+	{"/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf", "/usr/share/fonts/truetype/dejavu/DejaVuSans-Bold.ttf"},
+}
+
+// Receipt is the content of the receipt of a response
+type Receipt struct {
+	FormID      string
+	FormTitle   string
+	ResponseID  string
+	Revision    int
+	SubmittedAt time.Time
+	Answers     []Answer
+}
+
+// Answer is a question of the form and the answer of the response to it
+type Answer struct {
+	QuestionID string
+	Question   string
+	// Text is the answer as displayed, empty when unanswered
+	Text string
+	// Files are the names of the files uploaded in answer to file questions
+	Files []string
+}
+
+// Hash returns the verification hash of the receipt, the SHA-256 of its
+// answers as canonical JSON. Titles and unanswered questions are left out,
+// so the same response at the same revision hashes the same as the form is
+// edited, and a printed receipt can be checked against the response.
+func (r Receipt) Hash() string {
+	type answer struct {
+		QuestionID string   `json:"question_id"`
+		Text       string   `json:"text"`
+		Files      []string `json:"files"`
+	}
+	content := struct {
+		FormID      string   `json:"form_id"`
+		ResponseID  string   `json:"response_id"`
+		Revision    int      `json:"revision"`
+		SubmittedAt string   `json:"submitted_at"`
+		Answers     []answer `json:"answers"`
+	}{
+		FormID:      r.FormID,
+		ResponseID:  r.ResponseID,
+		Revision:    r.Revision,
+		SubmittedAt: r.SubmittedAt.UTC().Format(time.RFC3339Nano),
+		Answers:     make([]answer, 0, len(r.Answers)),
+	}
+	for _, a := range r.Answers {
+		if a.Text == "" && len(a.Files) == 0 {
+			continue
+		}
+		files := a.Files
+		if files == nil {
+			files = []string{}
+		}
+		content.Answers = append(content.Answers, answer{QuestionID: a.QuestionID, Text: a.Text, Files: files})
+	}
+	encoded, _ := json.Marshal(content)
+	sum := sha256.Sum256(encoded)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// Fonts are the TrueType fonts receipts are set in
+type Fonts struct {
+	Regular []byte
+	Bold    []byte
+}
+
+// LoadFonts reads the fonts at regularPath and boldPath. Without a path,
+// DejaVu Sans is used when installed, the Go fonts otherwise; without a
+// bold path, the regular font is used for headings too.
+func LoadFonts(regularPath, boldPath string) (Fonts, error) {
+	if regularPath == "" {
+		for _, candidate := range fontCandidates {
+			if _, err := os.Stat(candidate[0]); err == nil {
+				regularPath, boldPath = candidate[0], candidate[1]
+				break
+			}
+		}
+	}
+	if regularPath == "" {
+		return Fonts{Regular: goregular.TTF, Bold: gobold.TTF}, nil
+	}
+
+	regular, err := os.ReadFile(regularPath)
+	if err != nil {
+		return Fonts{}, fmt.Errorf("failed to read receipt font: %w", err)
+	}
+	fonts := Fonts{Regular: regular, Bold: regular}
+	if boldPath != "" {
+		if bold, err := os.ReadFile(boldPath); err == nil {
+			fonts.Bold = bold
+		}
+	}
+	return fonts, nil
+}
+
+// Renderer renders receipts as PDF documents
+type Renderer struct {
+	fonts Fonts
+}
+
+// NewRenderer creates a renderer setting receipts in fonts
+func NewRenderer(fonts Fonts) *Renderer {
+	return &Renderer{fonts: fonts}
+}
+
+// Render returns the PDF document of receipt
+func (r *Renderer) Render(receipt Receipt) ([]byte, error) {
+	hash := receipt.Hash()
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetCreator("X-Form", true)
+	pdf.SetTitle(sanitize(receipt.FormTitle), true)
+	pdf.SetMargins(pageMargin, pageMargin, pageMargin)
+	pdf.SetAutoPageBreak(true, pageMargin+footerHeight)
+	pdf.AddUTF8FontFromBytes("receipt", "", r.fonts.Regular)
+	pdf.AddUTF8FontFromBytes("receipt", "B", r.fonts.Bold)
+	pdf.AliasNbPages("{nb}")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-(pageMargin + footerHeight - 4))
+		pdf.SetFont("receipt", "", smallSize)
+		pdf.SetTextColor(110, 110, 110)
+		width, _ := pdf.GetPageSize()
+		pdf.CellFormat(width-2*pageMargin, smallLine, "Verification hash: "+hash, "", 1, "L", false, 0, "")
+		pdf.CellFormat(width-2*pageMargin, smallLine, fmt.Sprintf("Page %d of {nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+	})
+	pdf.AddPage()
+	if err := pdf.Error(); err != nil {
+		return nil, fmt.Errorf("failed to set up receipt: %w", err)
+	}
+
+	width, _ := pdf.GetPageSize()
+	contentWidth := width - 2*pageMargin
+
+	pdf.SetFont("receipt", "B", titleSize)
+	paragraph(pdf, receipt.FormTitle, contentWidth, 7.5)
+	pdf.Ln(1)
+	pdf.SetFont("receipt", "", smallSize+1)
+	pdf.SetTextColor(110, 110, 110)
+	paragraph(pdf, "Response receipt", contentWidth, smallLine+1)
+	pdf.SetTextColor(0, 0, 0)
+	pdf.Ln(3)
+
+	pdf.SetFont("receipt", "", textSize-1)
+	details := [][2]string{
+		{"Response ID", receipt.ResponseID},
+		{"Submitted", receipt.SubmittedAt.UTC().Format("2 January 2006, 15:04 MST")},
+	}
+	if receipt.Revision > 0 {
+		details = append(details, [2]string{"Revision", strconv.Itoa(receipt.Revision)})
+	}
+	for _, detail := range details {
+		pdf.CellFormat(32, lineHeight, detail[0], "", 0, "L", false, 0, "")
+		pdf.CellFormat(contentWidth-32, lineHeight, sanitize(detail[1]), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(2)
+	y := pdf.GetY()
+	pdf.SetDrawColor(200, 200, 200)
+	pdf.Line(pageMargin, y, width-pageMargin, y)
+	pdf.Ln(answerSpacing)
+
+	for _, answer := range receipt.Answers {
+		pdf.SetFont("receipt", "B", textSize)
+		paragraph(pdf, answer.Question, contentWidth, lineHeight)
+		pdf.SetFont("receipt", "", textSize)
+		switch {
+		case len(answer.Files) > 0:
+			for _, name := range answer.Files {
+				paragraph(pdf, name, contentWidth, lineHeight)
+			}
+		case strings.TrimSpace(answer.Text) == "":
+			pdf.SetTextColor(110, 110, 110)
+			paragraph(pdf, unansweredText, contentWidth, lineHeight)
+			pdf.SetTextColor(0, 0, 0)
+		default:
+			paragraph(pdf, answer.Text, contentWidth, lineHeight)
+		}
+		pdf.Ln(answerSpacing)
+	}
+
+	var out bytes.Buffer
+	if err := pdf.Output(&out); err != nil {
+		return nil, fmt.Errorf("failed to render receipt: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+// paragraph writes text wrapped to width, one paragraph per line of text.
+// Right-to-left paragraphs are aligned right, each of their lines reordered
+// for display.
+func paragraph(pdf *fpdf.Fpdf, text string, width, height float64) {
+	for _, line := range strings.Split(sanitize(text), "\n") {
+		rtl := isRightToLeft(line)
+		align := "L"
+		if rtl {
+			align = "R"
+		}
+		wrapped := pdf.SplitText(line, width)
+		if len(wrapped) == 0 {
+			wrapped = []string{""}
+		}
+		for _, segment := range wrapped {
+			pdf.CellFormat(width, height, visual(strings.TrimRight(segment, " "), rtl), "", 1, align, false, 0, "")
+		}
+	}
+}
+
+// isRightToLeft reports whether the first strong character of text is
+// right-to-left
+func isRightToLeft(text string) bool {
+	for _, r := range text {
+		properties, _ := bidi.LookupRune(r)
+		switch properties.Class() {
+		case bidi.L:
+			return false
+		case bidi.R, bidi.AL:
+			return true
+		}
+	}
+	return false
+}
+
+// visual reorders a line of text in logical order for display from left to
+// right: its right-to-left runs are reversed, and in a right-to-left line
+// the runs themselves are laid out from right to left.
+func visual(line string, rtl bool) string {
+	direction := bidi.LeftToRight
+	if rtl {
+		direction = bidi.RightToLeft
+	}
+	var p bidi.Paragraph
+	if _, err := p.SetString(line, bidi.DefaultDirection(direction)); err != nil {
+		return line
+	}
+	ordering, err := p.Order()
+	if err != nil {
+		return line
+	}
+
+	runs := make([]string, ordering.NumRuns())
+	for i := range runs {
+		run := ordering.Run(i)
+		runs[i] = run.String()
+		if run.Direction() == bidi.RightToLeft {
+			runs[i] = bidi.ReverseString(runs[i])
+		}
+	}
+	if rtl {
+		for i, j := 0, len(runs)-1; i < j; i, j = i+1, j-1 {
+			runs[i], runs[j] = runs[j], runs[i]
+		}
+	}
+	return strings.Join(runs, "")
+}
+
+// sanitize keeps the characters the PDF fonts can encode: tabs become
+// spaces, other control characters are dropped, and characters beyond the
+// Basic Multilingual Plane, such as emoji, are replaced
+func sanitize(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n':
+			return r
+		case r == '\t':
+			return ' '
+		case unicode.IsControl(r):
+			return -1
+		case r > 0xFFFF:
+			return unicode.ReplacementChar
+		}
+		return r
+	}, strings.ReplaceAll(text, "\r\n", "\n"))
+}
@@ -0,0 +1,243 @@
+package receipt
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+var submittedAt = time.Date(2026, 3, 14, 9, 26, 53, 0, time.UTC)
+
+func testReceipt() Receipt {
+	return Receipt{
+		FormID:      "6f1c2a9e-0d4b-4c8e-9a57-3e2b1f0c7d11",
+		FormTitle:   "Volunteer registration 2026",
+		ResponseID:  "resp-42",
+		Revision:    2,
+		SubmittedAt: submittedAt,
+		Answers: []Answer{
+			{QuestionID: "q1", Question: "Full name", Text: "Ada Lovelace"},
+			{QuestionID: "q2", Question: "Why do you want to volunteer?", Text: strings.Repeat("I enjoy helping out at community events. ", 12)},
+			{QuestionID: "q3", Question: "CV", Files: []string{"ada-cv.pdf", "references.docx"}},
+			{QuestionID: "q4", Question: "Dietary requirements"},
+		},
+	}
+}
+
+// pageText returns the lines of text of a rendered receipt, in the order
+// they are drawn. Receipts set text in UTF-8 fonts, whose strings are
+// UTF-16 encoded code points.
+func pageText(t *testing.T, pdf []byte) []string {
+	t.Helper()
+	streams := regexp.MustCompile(`(?s)stream\n(.*?)\nendstream`).FindAllSubmatch(pdf, -1)
+	text := regexp.MustCompile(`\(((?:\\.|[^\\)])*)\)\s*Tj`)
+
+	var lines []string
+	for _, stream := range streams {
+		content := stream[1]
+		if r, err := zlib.NewReader(bytes.NewReader(content)); err == nil {
+			if inflated, err := io.ReadAll(r); err == nil {
+				content = inflated
+			}
+		}
+		if !bytes.Contains(content, []byte("Tj")) {
+			continue
+		}
+		for _, match := range text.FindAllSubmatch(content, -1) {
+			lines = append(lines, decodeString(match[1]))
+		}
+	}
+	return lines
+}
+
+// decodeString decodes an escaped PDF string of UTF-16BE code units
+func decodeString(escaped []byte) string {
+	var raw []byte
+	for i := 0; i < len(escaped); i++ {
+		c := escaped[i]
+		if c != '\\' || i+1 == len(escaped) {
+			raw = append(raw, c)
+			continue
+		}
+		i++
+		switch escaped[i] {
+		case 'n':
+			raw = append(raw, '\n')
+		case 'r':
+			raw = append(raw, '\r')
+		case 't':
+			raw = append(raw, '\t')
+		default:
+			raw = append(raw, escaped[i])
+		}
+	}
+	units := make([]uint16, len(raw)/2)
+	for i := range units {
+		units[i] = uint16(raw[2*i])<<8 | uint16(raw[2*i+1])
+	}
+	return string(utf16.Decode(units))
+}
+
+func render(t *testing.T, receipt Receipt) []string {
+	t.Helper()
+	fonts, err := LoadFonts("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdf, err := NewRenderer(fonts).Render(receipt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(pdf, []byte("%PDF-")) {
+		t.Fatalf("receipt is not a PDF: %q", pdf[:16])
+	}
+	return pageText(t, pdf)
+}
+
+func indexOf(lines []string, line string) int {
+	for i, l := range lines {
+		if l == line {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestRenderLayout(t *testing.T) {
+	receipt := testReceipt()
+	lines := render(t, receipt)
+
+	// The header, then each question followed by its answer
+	order := []string{
+		"Volunteer registration 2026",
+		"Response receipt",
+		"Response ID", "resp-42",
+		"Submitted", "14 March 2026, 09:26 UTC",
+		"Revision", "2",
+		"Full name", "Ada Lovelace",
+		"Why do you want to volunteer?",
+		"CV", "ada-cv.pdf", "references.docx",
+		"Dietary requirements", unansweredText,
+		"Verification hash: " + receipt.Hash(),
+		"Page 1 of 1",
+	}
+	last := -1
+	for _, want := range order {
+		i := indexOf(lines, want)
+		if i < 0 {
+			t.Fatalf("receipt is missing %q; lines:\n%s", want, strings.Join(lines, "\n"))
+		}
+		if i < last {
+			t.Errorf("%q is drawn before the text preceding it", want)
+		}
+		last = i
+	}
+
+	// The long answer wraps over several lines, none wider than the page,
+	// and reads the same once joined
+	start := indexOf(lines, "Why do you want to volunteer?") + 1
+	end := indexOf(lines, "CV")
+	if end-start < 3 {
+		t.Fatalf("long answer is drawn on %d lines, want it wrapped", end-start)
+	}
+	joined := strings.Join(lines[start:end], " ")
+	if want := strings.TrimSpace(receipt.Answers[1].Text); joined != want {
+		t.Errorf("wrapped answer = %q, want %q", joined, want)
+	}
+	for _, line := range lines[start:end] {
+		if len(line) > 100 {
+			t.Errorf("line of %d characters overflows the page: %q", len(line), line)
+		}
+	}
+}
+
+func TestRenderPaginates(t *testing.T) {
+	receipt := testReceipt()
+	for i := 0; i < 60; i++ {
+		receipt.Answers = append(receipt.Answers, Answer{QuestionID: "extra", Question: "Question", Text: "Answer"})
+	}
+	lines := render(t, receipt)
+
+	// Every page is numbered out of the total and carries the hash
+	pages, hashes := 0, 0
+	for _, line := range lines {
+		if strings.HasPrefix(line, "Page ") {
+			pages++
+		}
+		if line == "Verification hash: "+receipt.Hash() {
+			hashes++
+		}
+	}
+	if pages < 2 {
+		t.Fatalf("long receipt is drawn on %d pages, want it paginated", pages)
+	}
+	for page := 1; page <= pages; page++ {
+		if want := fmt.Sprintf("Page %d of %d", page, pages); indexOf(lines, want) < 0 {
+			t.Errorf("receipt is missing %q", want)
+		}
+	}
+	if hashes != pages {
+		t.Errorf("verification hash is printed on %d of %d pages", hashes, pages)
+	}
+}
+
+func TestRenderRightToLeft(t *testing.T) {
+	receipt := testReceipt()
+	receipt.Answers = []Answer{
+		{QuestionID: "q1", Question: "כתובת", Text: "רחוב הרצל 12, תל אביב"},
+		{QuestionID: "q2", Question: "Comment", Text: "Visited (שלום) twice"},
+	}
+	lines := render(t, receipt)
+
+	// Lines are stored in display order, from left to right: the Hebrew
+	// runs reversed, the number kept, and brackets mirrored
+	for _, want := range []string{"תבותכ", "ביבא לת ,12 לצרה בוחר", "Visited (םולש) twice"} {
+		if indexOf(lines, want) < 0 {
+			t.Errorf("receipt is missing %q; lines:\n%s", want, strings.Join(lines, "\n"))
+		}
+	}
+}
+
+func TestRenderReplacesUnencodableCharacters(t *testing.T) {
+	receipt := testReceipt()
+	receipt.Answers = []Answer{{QuestionID: "q1", Question: "Mood", Text: "Great 🎉\tthanks\x07"}}
+	lines := render(t, receipt)
+	if indexOf(lines, "Great � thanks") < 0 {
+		t.Errorf("receipt is missing the sanitized answer; lines:\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestHash(t *testing.T) {
+	receipt := testReceipt()
+	hash := receipt.Hash()
+	if !strings.HasPrefix(hash, "sha256:") || len(hash) != len("sha256:")+64 {
+		t.Fatalf("hash = %q, want sha256:<hex>", hash)
+	}
+
+	same := testReceipt()
+	same.FormTitle = "Renamed form"
+	same.Answers[0].Question = "Name"
+	same.Answers = append(same.Answers, Answer{QuestionID: "q5", Question: "Added later"})
+	if same.Hash() != hash {
+		t.Error("hash changed with the titles and unanswered questions, which are not part of the response")
+	}
+
+	for name, change := range map[string]func(*Receipt){
+		"answer":   func(r *Receipt) { r.Answers[0].Text = "Ada King" },
+		"file":     func(r *Receipt) { r.Answers[2].Files = r.Answers[2].Files[:1] },
+		"revision": func(r *Receipt) { r.Revision = 3 },
+		"time":     func(r *Receipt) { r.SubmittedAt = r.SubmittedAt.Add(time.Second) },
+	} {
+		changed := testReceipt()
+		change(&changed)
+		if changed.Hash() == hash {
+			t.Errorf("hash did not change with the %s", name)
+		}
+	}
+}
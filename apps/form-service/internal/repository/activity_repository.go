@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+)
+
+// ActivityRepository defines the interface for reading the activity feed of
+// forms. Activities are written by the form and question repositories, in
+// the transaction of the change they describe; see WithActivity.
+type ActivityRepository interface {
+	// List returns up to limit activities of the form before the cursor,
+	// newest first, of the given actions or of any action when empty
+	List(ctx context.Context, formID uuid.UUID, actions []models.ActivityAction, before ActivityCursor, limit int) ([]*models.FormActivity, error)
+}
+
+// ActivityCursor is the position of an activity in the feed of its form,
+// which is ordered by (created_at, id) descending. The zero cursor is before
+// the newest activity.
+type ActivityCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// activityKey carries the pendingActivity of a change in its context
+type activityKey struct{}
+
+// pendingActivity is an activity to record with the change it describes,
+// and how many activities of the form are kept
+type pendingActivity struct {
+	activity *models.FormActivity
+	keep     int
+}
+
+// WithActivity returns ctx carrying an activity the form and question
+// repositories record in the transaction of the change made under ctx. The
+// oldest activities of the form are deleted in the same transaction so that
+// it keeps at most keep, or all of them when keep is 0.
+func WithActivity(ctx context.Context, activity *models.FormActivity, keep int) context.Context {
+	return context.WithValue(ctx, activityKey{}, pendingActivity{activity: activity, keep: keep})
+}
+
+// ActivityFrom returns the activity ctx carries, nil when it carries none
+func ActivityFrom(ctx context.Context) *models.FormActivity {
+	pending, _ := ctx.Value(activityKey{}).(pendingActivity)
+	return pending.activity
+}
+
+// recordActivity records the activity ctx carries, if any, within tx
+func recordActivity(ctx context.Context, tx *gorm.DB) error {
+	pending, ok := ctx.Value(activityKey{}).(pendingActivity)
+	if !ok || pending.activity == nil {
+		return nil
+	}
+	if err := tx.Create(pending.activity).Error; err != nil {
+		return err
+	}
+	if pending.keep <= 0 {
+		return nil
+	}
+	return tx.Exec(`DELETE FROM form_activities WHERE id IN (
+		SELECT id FROM form_activities WHERE form_id = ?
+		ORDER BY created_at DESC, id DESC OFFSET ?)`, pending.activity.FormID, pending.keep).Error
+}
+
+// inTransaction runs fn in a transaction when ctx carries an activity, so
+// that the activity is recorded with the change fn makes, and on db
+// otherwise
+func inTransaction(ctx context.Context, db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	if ActivityFrom(ctx) == nil {
+		return fn(db.WithContext(ctx))
+	}
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		return recordActivity(ctx, tx)
+	})
+}
+
+// activityRepository implements ActivityRepository interface
+type activityRepository struct {
+	db *gorm.DB
+}
+
+// NewActivityRepository creates a new activity repository instance
+func NewActivityRepository(db *gorm.DB) ActivityRepository {
+	return &activityRepository{db: db}
+}
+
+// List returns a page of the activity feed of the form
+func (r *activityRepository) List(ctx context.Context, formID uuid.UUID, actions []models.ActivityAction, before ActivityCursor, limit int) ([]*models.FormActivity, error) {
+	query := r.db.WithContext(ctx).Where("form_id = ?", formID)
+	if len(actions) > 0 {
+		query = query.Where("action IN ?", actions)
+	}
+	if !before.CreatedAt.IsZero() {
+		query = query.Where("(created_at, id) < (?, ?)", before.CreatedAt, before.ID)
+	}
+
+	var activities []*models.FormActivity
+	err := query.
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&activities).Error
+	if err != nil {
+		return nil, err
+	}
+	return activities, nil
+}
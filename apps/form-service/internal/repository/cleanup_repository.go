@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+)
+
+// ErrCleanupJobNotFound is returned for cleanup jobs that don't exist
+var ErrCleanupJobNotFound = errors.New("cleanup job not found")
+
+// CleanupJobRepository stores the cleanup jobs of admins. Replicas claim
+// queued jobs, so each runs on a single replica at a time.
+type CleanupJobRepository interface {
+	Create(ctx context.Context, job *models.CleanupJob) error
+	Get(ctx context.Context, id uuid.UUID) (*models.CleanupJob, error)
+	// Claim marks the oldest queued job running and returns it, nil when
+	// there is none. A running job whose progress was last saved before
+	// staleBefore was abandoned by its replica and is claimed again.
+	Claim(ctx context.Context, now, staleBefore time.Time) (*models.CleanupJob, error)
+	// SaveProgress saves the counts and cursor of a job, reporting false
+	// when the job is no longer running because it was cancelled
+	SaveProgress(ctx context.Context, job *models.CleanupJob) (bool, error)
+	// Finish records the final status of a running job
+	Finish(ctx context.Context, job *models.CleanupJob) error
+	// Cancel cancels a job that has not finished, reporting false when it had
+	Cancel(ctx context.Context, id uuid.UUID, now time.Time) (bool, error)
+}
+
+// cleanupJobRepository implements CleanupJobRepository interface
+type cleanupJobRepository struct {
+	db *gorm.DB
+}
+
+// NewCleanupJobRepository creates a new cleanup job repository instance
+func NewCleanupJobRepository(db *gorm.DB) CleanupJobRepository {
+	return &cleanupJobRepository{db: db}
+}
+
+// Create records a new job
+func (r *cleanupJobRepository) Create(ctx context.Context, job *models.CleanupJob) error {
+	return r.db.WithContext(ctx).Create(job).Error
+}
+
+// Get retrieves a job by its ID
+func (r *cleanupJobRepository) Get(ctx context.Context, id uuid.UUID) (*models.CleanupJob, error) {
+	var job models.CleanupJob
+
+	err := r.db.WithContext(ctx).First(&job, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCleanupJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// Claim marks the oldest claimable job running. Jobs locked by another
+// replica claiming them are skipped.
+func (r *cleanupJobRepository) Claim(ctx context.Context, now, staleBefore time.Time) (*models.CleanupJob, error) {
+	var jobs []*models.CleanupJob
+
+	err := r.db.WithContext(ctx).Raw(`UPDATE form_cleanup_jobs
+		SET status = ?, started_at = COALESCE(started_at, ?), updated_at = ?
+		WHERE id = (
+			SELECT id FROM form_cleanup_jobs
+			WHERE status = ? OR (status = ? AND updated_at < ?)
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		models.CleanupJobRunning, now, now,
+		models.CleanupJobQueued, models.CleanupJobRunning, staleBefore,
+	).Scan(&jobs).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+	return jobs[0], nil
+}
+
+// SaveProgress saves the counts and cursor of a job, then reports whether it
+// is still running. The counts of a job cancelled meanwhile are saved too,
+// since its forms were archived.
+func (r *cleanupJobRepository) SaveProgress(ctx context.Context, job *models.CleanupJob) (bool, error) {
+	var statuses []models.CleanupJobStatus
+
+	err := r.db.WithContext(ctx).Raw(`UPDATE form_cleanup_jobs
+		SET matched = ?, archived = ?, failed = ?, cursor_created_at = ?, cursor_id = ?, updated_at = ?
+		WHERE id = ?
+		RETURNING status`,
+		job.Matched, job.Archived, job.Failed, job.CursorCreatedAt, job.CursorID, time.Now(), job.ID,
+	).Scan(&statuses).Error
+	if err != nil {
+		return false, err
+	}
+	return len(statuses) == 1 && statuses[0] == models.CleanupJobRunning, nil
+}
+
+// Finish records the final status, counts and error of a running job. A job
+// cancelled meanwhile stays cancelled.
+func (r *cleanupJobRepository) Finish(ctx context.Context, job *models.CleanupJob) error {
+	return r.db.WithContext(ctx).
+		Model(&models.CleanupJob{}).
+		Where("id = ? AND status = ?", job.ID, models.CleanupJobRunning).
+		Updates(map[string]interface{}{
+			"status":      job.Status,
+			"matched":     job.Matched,
+			"archived":    job.Archived,
+			"failed":      job.Failed,
+			"error":       job.Error,
+			"finished_at": job.FinishedAt,
+			"updated_at":  time.Now(),
+		}).Error
+}
+
+// Cancel cancels a queued or running job
+func (r *cleanupJobRepository) Cancel(ctx context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.CleanupJob{}).
+		Where("id = ? AND status IN ?", id, []models.CleanupJobStatus{models.CleanupJobQueued, models.CleanupJobRunning}).
+		Updates(map[string]interface{}{
+			"status":      models.CleanupJobCancelled,
+			"finished_at": now,
+			"updated_at":  now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
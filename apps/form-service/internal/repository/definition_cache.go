@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrDefinitionNotCached is returned when the cache holds no definition of a
+// form, or none of the version asked for
+var ErrDefinitionNotCached = errors.New("definition not cached")
+
+// DefinitionCache keeps the encoded definitions of published forms, by
+// form and version
+type DefinitionCache interface {
+	// Get retrieves the definition of the form at version, or at the
+	// version cached last for version 0
+	Get(ctx context.Context, formID uuid.UUID, version int) ([]byte, error)
+	// Set stores the definition of the form at version for ttl, making it
+	// the one Get finds for version 0
+	Set(ctx context.Context, formID uuid.UUID, version int, data []byte, ttl time.Duration) error
+	// Delete drops the definition of the form cached last
+	Delete(ctx context.Context, formID uuid.UUID) error
+}
+
+// redisDefinitionCache stores a definition under form:def:{id}:{version},
+// with form:def:{id} holding the version cached last
+type redisDefinitionCache struct {
+	client *redis.Client
+}
+
+// NewRedisDefinitionCache creates a definition cache backed by Redis
+func NewRedisDefinitionCache(client *redis.Client) DefinitionCache {
+	return &redisDefinitionCache{client: client}
+}
+
+func definitionVersionKey(formID uuid.UUID) string {
+	return fmt.Sprintf("form:def:%s", formID)
+}
+
+func definitionCacheKey(formID uuid.UUID, version int) string {
+	return fmt.Sprintf("form:def:%s:%d", formID, version)
+}
+
+// Get retrieves a definition, looking its version up first when version is 0
+func (c *redisDefinitionCache) Get(ctx context.Context, formID uuid.UUID, version int) ([]byte, error) {
+	if version == 0 {
+		current, err := c.client.Get(ctx, definitionVersionKey(formID)).Int()
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrDefinitionNotCached
+		}
+		if err != nil {
+			return nil, err
+		}
+		version = current
+	}
+
+	data, err := c.client.Get(ctx, definitionCacheKey(formID, version)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrDefinitionNotCached
+	}
+	return data, err
+}
+
+// Set stores a definition and its version in one transaction
+func (c *redisDefinitionCache) Set(ctx context.Context, formID uuid.UUID, version int, data []byte, ttl time.Duration) error {
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, definitionCacheKey(formID, version), data, ttl)
+		pipe.Set(ctx, definitionVersionKey(formID), strconv.Itoa(version), ttl)
+		return nil
+	})
+	return err
+}
+
+// Delete drops the version of the form and the definition it points to
+func (c *redisDefinitionCache) Delete(ctx context.Context, formID uuid.UUID) error {
+	version, err := c.client.GetDel(ctx, definitionVersionKey(formID)).Int()
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return c.client.Del(ctx, definitionCacheKey(formID, version)).Err()
+}
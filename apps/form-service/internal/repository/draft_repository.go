@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+)
+
+// ErrDraftNotFound is returned when no unexpired draft exists for a form and owner
+var ErrDraftNotFound = errors.New("draft not found")
+
+// DraftCache is the primary, TTL-bound store of response drafts
+type DraftCache interface {
+	Get(ctx context.Context, formID uuid.UUID, ownerKey string) (*models.ResponseDraft, error)
+	Set(ctx context.Context, draft *models.ResponseDraft) error
+	// Take atomically reads and deletes a draft
+	Take(ctx context.Context, formID uuid.UUID, ownerKey string) (*models.ResponseDraft, error)
+}
+
+// DraftRepository is the durable write-through store for drafts of logged-in users
+type DraftRepository interface {
+	Upsert(ctx context.Context, draft *models.ResponseDraft) error
+	Get(ctx context.Context, formID uuid.UUID, ownerKey string, now time.Time) (*models.ResponseDraft, error)
+	// Take atomically reads and deletes a draft
+	Take(ctx context.Context, formID uuid.UUID, ownerKey string, now time.Time) (*models.ResponseDraft, error)
+	// DeleteExpired removes drafts that expired before now
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+	// Abandoned returns up to limit unexpired drafts of a form last saved
+	// before idleSince, most recently saved first
+	Abandoned(ctx context.Context, formID uuid.UUID, idleSince, now time.Time, limit int) ([]models.ResponseDraft, error)
+}
+
+// redisDraftCache stores drafts as JSON values expiring with the draft
+type redisDraftCache struct {
+	client *redis.Client
+}
+
+// NewRedisDraftCache creates a draft cache backed by Redis
+func NewRedisDraftCache(client *redis.Client) DraftCache {
+	return &redisDraftCache{client: client}
+}
+
+func draftCacheKey(formID uuid.UUID, ownerKey string) string {
+	return fmt.Sprintf("form:%s:draft:%s", formID, ownerKey)
+}
+
+// Get retrieves a draft
+func (c *redisDraftCache) Get(ctx context.Context, formID uuid.UUID, ownerKey string) (*models.ResponseDraft, error) {
+	data, err := c.client.Get(ctx, draftCacheKey(formID, ownerKey)).Bytes()
+	return decodeCachedDraft(data, err)
+}
+
+// Set stores a draft until its expiry
+func (c *redisDraftCache) Set(ctx context.Context, draft *models.ResponseDraft) error {
+	data, err := json.Marshal(draft)
+	if err != nil {
+		return fmt.Errorf("failed to encode draft: %w", err)
+	}
+	return c.client.Set(ctx, draftCacheKey(draft.FormID, draft.OwnerKey), data, time.Until(draft.ExpiresAt)).Err()
+}
+
+// Take reads and deletes a draft with GETDEL
+func (c *redisDraftCache) Take(ctx context.Context, formID uuid.UUID, ownerKey string) (*models.ResponseDraft, error) {
+	data, err := c.client.GetDel(ctx, draftCacheKey(formID, ownerKey)).Bytes()
+	return decodeCachedDraft(data, err)
+}
+
+func decodeCachedDraft(data []byte, err error) (*models.ResponseDraft, error) {
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrDraftNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var draft models.ResponseDraft
+	if err := json.Unmarshal(data, &draft); err != nil {
+		return nil, fmt.Errorf("failed to decode draft: %w", err)
+	}
+	return &draft, nil
+}
+
+// draftRepository implements DraftRepository interface
+type draftRepository struct {
+	db *gorm.DB
+}
+
+// NewDraftRepository creates a new draft repository instance
+func NewDraftRepository(db *gorm.DB) DraftRepository {
+	return &draftRepository{db: db}
+}
+
+// Upsert creates or replaces the draft of an owner
+func (r *draftRepository) Upsert(ctx context.Context, draft *models.ResponseDraft) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "form_id"}, {Name: "owner_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_id", "answers", "size_bytes", "updated_at", "expires_at"}),
+	}).Create(draft).Error
+}
+
+// Get retrieves an unexpired draft
+func (r *draftRepository) Get(ctx context.Context, formID uuid.UUID, ownerKey string, now time.Time) (*models.ResponseDraft, error) {
+	var draft models.ResponseDraft
+
+	err := r.db.WithContext(ctx).
+		Where("form_id = ? AND owner_key = ? AND expires_at > ?", formID, ownerKey, now).
+		First(&draft).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDraftNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &draft, nil
+}
+
+// Take deletes a draft with DELETE ... RETURNING, so concurrent callers can't
+// both receive it. Expired drafts are deleted but not returned.
+func (r *draftRepository) Take(ctx context.Context, formID uuid.UUID, ownerKey string, now time.Time) (*models.ResponseDraft, error) {
+	var drafts []models.ResponseDraft
+
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Returning{}).
+		Where("form_id = ? AND owner_key = ?", formID, ownerKey).
+		Delete(&drafts).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(drafts) == 0 || drafts[0].IsExpired(now) {
+		return nil, ErrDraftNotFound
+	}
+
+	return &drafts[0], nil
+}
+
+// DeleteExpired removes expired drafts
+func (r *draftRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&models.ResponseDraft{})
+	return result.RowsAffected, result.Error
+}
+
+// Abandoned returns the drafts of a form left idle since idleSince. Drafts
+// are deleted on submission, so these are the drafts respondents gave up on.
+func (r *draftRepository) Abandoned(ctx context.Context, formID uuid.UUID, idleSince, now time.Time, limit int) ([]models.ResponseDraft, error) {
+	var drafts []models.ResponseDraft
+
+	err := r.db.WithContext(ctx).
+		Where("form_id = ? AND updated_at < ? AND expires_at > ?", formID, idleSince, now).
+		Order("updated_at DESC").
+		Limit(limit).
+		Find(&drafts).Error
+	return drafts, err
+}
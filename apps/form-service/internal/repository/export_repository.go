@@ -0,0 +1,373 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+)
+
+var (
+	// ErrExportJobNotFound is returned for export jobs that don't exist
+	ErrExportJobNotFound = errors.New("export job not found")
+	// ErrExportJobFinished is returned when cancelling a job that ended
+	ErrExportJobFinished = errors.New("export job already finished")
+	// ErrExportJobNotRunning is returned when saving the progress of a job
+	// that is no longer running, having been cancelled
+	ErrExportJobNotRunning = errors.New("export job is not running")
+)
+
+// exportClaimLockID is the key of the advisory lock held while claiming a
+// job, so the running jobs counted hold across replicas
+const exportClaimLockID int64 = 5_318_008_833
+
+// ExportSchedule bounds the export jobs running at a time. Zero limits are
+// unlimited.
+type ExportSchedule struct {
+	// MaxRunning caps the jobs running across replicas
+	MaxRunning int
+	// MaxRunningPerOrganization caps the jobs of an organization running
+	MaxRunningPerOrganization int
+}
+
+// ExportJobRepository stores the response exports of forms. Replicas claim
+// queued jobs, so each runs on a single replica at a time.
+type ExportJobRepository interface {
+	Create(ctx context.Context, job *models.ExportJob) error
+	Get(ctx context.Context, id uuid.UUID) (*models.ExportJob, error)
+	// Claim marks the next job to run running and returns it, nil when no
+	// job can run within schedule. Jobs are picked by NextExportJob. A
+	// running job whose progress was last saved before staleBefore was
+	// abandoned by its replica and is claimed again.
+	Claim(ctx context.Context, now, staleBefore time.Time, schedule ExportSchedule) (*models.ExportJob, error)
+	// QueuePosition returns the place of a queued job among the jobs of its
+	// organization waiting to run, from 1
+	QueuePosition(ctx context.Context, job *models.ExportJob) (int, error)
+	// QueueDepths counts the queued jobs by organization
+	QueueDepths(ctx context.Context) (map[uuid.UUID]int, error)
+	// SaveProgress saves the counts of a running job, failing with
+	// ErrExportJobNotRunning once it was cancelled
+	SaveProgress(ctx context.Context, job *models.ExportJob) error
+	// Cancel cancels a queued or running job and returns it; running jobs
+	// stop at their next progress save. Finished jobs fail with
+	// ErrExportJobFinished.
+	Cancel(ctx context.Context, id uuid.UUID, now time.Time) (*models.ExportJob, error)
+	// Finish records the final status of a running job
+	Finish(ctx context.Context, job *models.ExportJob) error
+}
+
+// NextExportJob picks the job to run among candidates, the oldest job
+// waiting of each organization and priority, given the jobs running by
+// organization and when each organization last had a job started:
+//
+//   - no job runs once schedule.MaxRunning jobs are running, and no job of
+//     an organization running schedule.MaxRunningPerOrganization jobs
+//   - low priority jobs only run when no normal job can run instead
+//   - organizations take turns: the job of the organization whose last job
+//     started the longest ago runs, the oldest job breaking ties
+//
+// It returns nil when no job can run.
+func NextExportJob(candidates []*models.ExportJob, running map[uuid.UUID]int, lastStarted map[uuid.UUID]time.Time, schedule ExportSchedule) *models.ExportJob {
+	if schedule.MaxRunning > 0 {
+		total := 0
+		for _, jobs := range running {
+			total += jobs
+		}
+		if total >= schedule.MaxRunning {
+			return nil
+		}
+	}
+
+	var next *models.ExportJob
+	for _, job := range candidates {
+		if schedule.MaxRunningPerOrganization > 0 && running[job.OrganizationID] >= schedule.MaxRunningPerOrganization {
+			continue
+		}
+		if next == nil || runsBefore(job, next, lastStarted) {
+			next = job
+		}
+	}
+	return next
+}
+
+// runsBefore reports whether job a runs before job b
+func runsBefore(a, b *models.ExportJob, lastStarted map[uuid.UUID]time.Time) bool {
+	if a.Priority != b.Priority {
+		return a.Priority != models.ExportPriorityLow
+	}
+	if startedA, startedB := lastStarted[a.OrganizationID], lastStarted[b.OrganizationID]; !startedA.Equal(startedB) {
+		return startedA.Before(startedB)
+	}
+	return a.CreatedAt.Before(b.CreatedAt)
+}
+
+// exportJobRepository implements ExportJobRepository interface
+type exportJobRepository struct {
+	db *gorm.DB
+}
+
+// NewExportJobRepository creates a new export job repository instance
+func NewExportJobRepository(db *gorm.DB) ExportJobRepository {
+	return &exportJobRepository{db: db}
+}
+
+// Create records a new job
+func (r *exportJobRepository) Create(ctx context.Context, job *models.ExportJob) error {
+	return r.db.WithContext(ctx).Create(job).Error
+}
+
+// Get retrieves a job by its ID
+func (r *exportJobRepository) Get(ctx context.Context, id uuid.UUID) (*models.ExportJob, error) {
+	var job models.ExportJob
+
+	err := r.db.WithContext(ctx).First(&job, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrExportJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// Claim marks the job picked by NextExportJob running, with its progress
+// reset since it runs from the start. Replicas claim one at a time.
+func (r *exportJobRepository) Claim(ctx context.Context, now, staleBefore time.Time, schedule ExportSchedule) (*models.ExportJob, error) {
+	var claimed *models.ExportJob
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", exportClaimLockID).Error; err != nil {
+			return err
+		}
+
+		var counts []struct {
+			OrganizationID uuid.UUID
+			Jobs           int
+		}
+		err := tx.Model(&models.ExportJob{}).
+			Select("organization_id, COUNT(*) AS jobs").
+			Where("status = ? AND updated_at >= ?", models.ExportJobRunning, staleBefore).
+			Group("organization_id").
+			Scan(&counts).Error
+		if err != nil {
+			return err
+		}
+		running := make(map[uuid.UUID]int, len(counts))
+		for _, count := range counts {
+			running[count.OrganizationID] += count.Jobs
+		}
+
+		var candidates []*models.ExportJob
+		err = tx.Raw(`SELECT DISTINCT ON (organization_id, priority) * FROM form_export_jobs
+			WHERE status = ? OR (status = ? AND updated_at < ?)
+			ORDER BY organization_id, priority, created_at`,
+			models.ExportJobQueued, models.ExportJobRunning, staleBefore,
+		).Scan(&candidates).Error
+		if err != nil || len(candidates) == 0 {
+			return err
+		}
+
+		orgIDs := make([]uuid.UUID, len(candidates))
+		for i, job := range candidates {
+			orgIDs[i] = job.OrganizationID
+		}
+		var starts []struct {
+			OrganizationID uuid.UUID
+			StartedAt      time.Time
+		}
+		err = tx.Model(&models.ExportJob{}).
+			Select("organization_id, MAX(started_at) AS started_at").
+			Where("organization_id IN ? AND started_at IS NOT NULL", orgIDs).
+			Group("organization_id").
+			Scan(&starts).Error
+		if err != nil {
+			return err
+		}
+		lastStarted := make(map[uuid.UUID]time.Time, len(starts))
+		for _, start := range starts {
+			lastStarted[start.OrganizationID] = start.StartedAt
+		}
+
+		next := NextExportJob(candidates, running, lastStarted, schedule)
+		if next == nil {
+			return nil
+		}
+		var jobs []*models.ExportJob
+		err = tx.Raw(`UPDATE form_export_jobs
+			SET status = ?, started_at = ?, updated_at = ?,
+				responses = 0, bytes_written = 0, files_written = 0, files_skipped = 0
+			WHERE id = ?
+			RETURNING *`,
+			models.ExportJobRunning, now, now, next.ID,
+		).Scan(&jobs).Error
+		if err != nil || len(jobs) == 0 {
+			return err
+		}
+		claimed = jobs[0]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+// QueuePosition counts the queued jobs of the organization running before
+// the job: those of a higher priority, and the older ones of its priority
+func (r *exportJobRepository) QueuePosition(ctx context.Context, job *models.ExportJob) (int, error) {
+	query := r.db.WithContext(ctx).
+		Model(&models.ExportJob{}).
+		Where("organization_id = ? AND status = ? AND id <> ?", job.OrganizationID, models.ExportJobQueued, job.ID)
+	if job.Priority == models.ExportPriorityLow {
+		query = query.Where("(priority <> ? OR created_at < ?)", models.ExportPriorityLow, job.CreatedAt)
+	} else {
+		query = query.Where("priority <> ? AND created_at < ?", models.ExportPriorityLow, job.CreatedAt)
+	}
+
+	var ahead int64
+	if err := query.Count(&ahead).Error; err != nil {
+		return 0, err
+	}
+	return int(ahead) + 1, nil
+}
+
+// QueueDepths counts the queued jobs by organization
+func (r *exportJobRepository) QueueDepths(ctx context.Context) (map[uuid.UUID]int, error) {
+	var counts []struct {
+		OrganizationID uuid.UUID
+		Jobs           int
+	}
+	err := r.db.WithContext(ctx).
+		Model(&models.ExportJob{}).
+		Select("organization_id, COUNT(*) AS jobs").
+		Where("status = ?", models.ExportJobQueued).
+		Group("organization_id").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	depths := make(map[uuid.UUID]int, len(counts))
+	for _, count := range counts {
+		depths[count.OrganizationID] += count.Jobs
+	}
+	return depths, nil
+}
+
+// SaveProgress saves the counts of a running job
+func (r *exportJobRepository) SaveProgress(ctx context.Context, job *models.ExportJob) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.ExportJob{}).
+		Where("id = ? AND status = ?", job.ID, models.ExportJobRunning).
+		Updates(map[string]interface{}{
+			"responses":     job.Responses,
+			"bytes_written": job.BytesWritten,
+			"files_written": job.FilesWritten,
+			"files_skipped": job.FilesSkipped,
+			"updated_at":    time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrExportJobNotRunning
+	}
+	return nil
+}
+
+// Cancel marks a queued or running job cancelled
+func (r *exportJobRepository) Cancel(ctx context.Context, id uuid.UUID, now time.Time) (*models.ExportJob, error) {
+	var jobs []*models.ExportJob
+
+	err := r.db.WithContext(ctx).Raw(`UPDATE form_export_jobs
+		SET status = ?, finished_at = ?, updated_at = ?
+		WHERE id = ? AND status IN (?, ?)
+		RETURNING *`,
+		models.ExportJobCancelled, now, now, id, models.ExportJobQueued, models.ExportJobRunning,
+	).Scan(&jobs).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		if _, err := r.Get(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrExportJobFinished
+	}
+	return jobs[0], nil
+}
+
+// Finish records the final status, counts, object and error of a running job
+func (r *exportJobRepository) Finish(ctx context.Context, job *models.ExportJob) error {
+	return r.db.WithContext(ctx).
+		Model(&models.ExportJob{}).
+		Where("id = ? AND status = ?", job.ID, models.ExportJobRunning).
+		Updates(map[string]interface{}{
+			"status":        job.Status,
+			"responses":     job.Responses,
+			"bytes_written": job.BytesWritten,
+			"files_written": job.FilesWritten,
+			"files_skipped": job.FilesSkipped,
+			"object_key":    job.ObjectKey,
+			"error":         job.Error,
+			"finished_at":   job.FinishedAt,
+			"updated_at":    time.Now(),
+		}).Error
+}
+
+// LinkRedemptions records the single-use download links used
+type LinkRedemptions interface {
+	// Redeem records the link as used by redemption until it expires,
+	// reporting false when it was used already
+	Redeem(ctx context.Context, linkID uuid.UUID, redemption string, expiresAt time.Time) (bool, error)
+	// Redemption returns the redemption the link was used by, empty while
+	// it is unused
+	Redemption(ctx context.Context, linkID uuid.UUID) (string, error)
+}
+
+// redisLinkRedemptions keeps a key per link used, holding its redemption
+// and expiring with the link
+type redisLinkRedemptions struct {
+	client *redis.Client
+}
+
+// NewRedisLinkRedemptions creates link redemptions backed by Redis
+func NewRedisLinkRedemptions(client *redis.Client) LinkRedemptions {
+	return &redisLinkRedemptions{client: client}
+}
+
+// Redeem takes the key of the link with SET NX
+func (r *redisLinkRedemptions) Redeem(ctx context.Context, linkID uuid.UUID, redemption string, expiresAt time.Time) (bool, error) {
+	ttl := time.Until(expiresAt)
+	if ttl < time.Second {
+		ttl = time.Second
+	}
+	redeemed, err := r.client.SetNX(ctx, redemptionKey(linkID), redemption, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to redeem download link: %w", err)
+	}
+	return redeemed, nil
+}
+
+// Redemption reads the key of the link
+func (r *redisLinkRedemptions) Redemption(ctx context.Context, linkID uuid.UUID) (string, error) {
+	redemption, err := r.client.Get(ctx, redemptionKey(linkID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read download link redemption: %w", err)
+	}
+	return redemption, nil
+}
+
+func redemptionKey(linkID uuid.UUID) string {
+	return "form-service:download-link:" + linkID.String()
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+)
+
+func TestNextExportJob(t *testing.T) {
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+	at := func(minutes int) time.Time { return time.Date(2024, 5, 1, 9, minutes, 0, 0, time.UTC) }
+	job := func(org uuid.UUID, priority models.ExportPriority, created int) *models.ExportJob {
+		return &models.ExportJob{ID: uuid.New(), OrganizationID: org, Priority: priority, CreatedAt: at(created)}
+	}
+	normalA, lowA := job(a, models.ExportPriorityNormal, 0), job(a, models.ExportPriorityLow, 1)
+	normalB, lowC := job(b, models.ExportPriorityNormal, 5), job(c, models.ExportPriorityLow, 2)
+	limits := ExportSchedule{MaxRunning: 4, MaxRunningPerOrganization: 2}
+
+	for _, tc := range []struct {
+		name        string
+		candidates  []*models.ExportJob
+		running     map[uuid.UUID]int
+		lastStarted map[uuid.UUID]time.Time
+		schedule    ExportSchedule
+		want        *models.ExportJob
+	}{
+		{"nothing queued", nil, nil, nil, limits, nil},
+		{"oldest first", []*models.ExportJob{normalB, normalA}, nil, nil, limits, normalA},
+		{"organizations take turns", []*models.ExportJob{normalA, normalB},
+			map[uuid.UUID]int{a: 1}, map[uuid.UUID]time.Time{a: at(10)}, limits, normalB},
+		{"least recently served", []*models.ExportJob{normalA, normalB},
+			nil, map[uuid.UUID]time.Time{a: at(10), b: at(20)}, limits, normalA},
+		{"organization at its limit", []*models.ExportJob{normalA, normalB},
+			map[uuid.UUID]int{b: 2}, map[uuid.UUID]time.Time{a: at(30)}, limits, normalA},
+		{"global limit", []*models.ExportJob{normalA, normalB},
+			map[uuid.UUID]int{c: 2, uuid.Nil: 2}, nil, limits, nil},
+		{"normal before low", []*models.ExportJob{lowA, lowC, normalB}, nil, nil, limits, normalB},
+		{"low when no normal job can run", []*models.ExportJob{lowC, normalA},
+			map[uuid.UUID]int{a: 2}, nil, limits, lowC},
+		{"unlimited", []*models.ExportJob{normalA},
+			map[uuid.UUID]int{a: 10}, nil, ExportSchedule{}, normalA},
+	} {
+		if got := NextExportJob(tc.candidates, tc.running, tc.lastStarted, tc.schedule); got != tc.want {
+			t.Errorf("%s: NextExportJob = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestExportJobClaim(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	jobs := NewExportJobRepository(db)
+	large, small := uuid.New(), uuid.New()
+	schedule := ExportSchedule{MaxRunning: 3, MaxRunningPerOrganization: 2}
+
+	create := func(org uuid.UUID, priority models.ExportPriority) *models.ExportJob {
+		job := &models.ExportJob{FormID: uuid.New(), OrganizationID: org, RequestedBy: uuid.New(),
+			Format: models.ExportFormatCSV, Priority: priority, Status: models.ExportJobQueued}
+		if err := jobs.Create(ctx, job); err != nil {
+			t.Fatal(err)
+		}
+		return job
+	}
+	var queued []*models.ExportJob
+	for i := 0; i < 5; i++ {
+		queued = append(queued, create(large, models.ExportPriorityNormal))
+	}
+	bulk := create(small, models.ExportPriorityLow)
+	smallJob := create(small, models.ExportPriorityNormal)
+
+	now := time.Now()
+	var claimed []uuid.UUID
+	for {
+		job, err := jobs.Claim(ctx, now, now.Add(-time.Hour), schedule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job == nil {
+			break
+		}
+		if job.Status != models.ExportJobRunning {
+			t.Errorf("claimed job %s is %s", job.ID, job.Status)
+		}
+		claimed = append(claimed, job.ID)
+		now = now.Add(time.Second)
+	}
+	if len(claimed) != 3 || claimed[0] != queued[0].ID || claimed[1] != smallJob.ID || claimed[2] != queued[1].ID {
+		t.Fatalf("claimed %v, want the first job of the large organization, the normal job of the small one, then the second", claimed)
+	}
+
+	if position, err := jobs.QueuePosition(ctx, queued[4]); err != nil || position != 3 {
+		t.Errorf("QueuePosition = %d, %v; want 3", position, err)
+	}
+	if position, err := jobs.QueuePosition(ctx, bulk); err != nil || position != 1 {
+		t.Errorf("QueuePosition of the low priority job = %d, %v; want 1", position, err)
+	}
+	if depths, err := jobs.QueueDepths(ctx); err != nil || depths[large] != 3 || depths[small] != 1 {
+		t.Errorf("QueueDepths = %v, %v; want 3 and 1", depths, err)
+	}
+
+	// A cancelled job stops saving its progress, and frees its place
+	running, err := jobs.Cancel(ctx, claimed[0], now)
+	if err != nil || running.Status != models.ExportJobCancelled {
+		t.Fatalf("Cancel = %+v, %v", running, err)
+	}
+	if err := jobs.SaveProgress(ctx, running); !errors.Is(err, ErrExportJobNotRunning) {
+		t.Errorf("SaveProgress of a cancelled job: err = %v, want ErrExportJobNotRunning", err)
+	}
+	if _, err := jobs.Cancel(ctx, claimed[0], now); !errors.Is(err, ErrExportJobFinished) {
+		t.Errorf("second Cancel: err = %v, want ErrExportJobFinished", err)
+	}
+	if _, err := jobs.Cancel(ctx, uuid.New(), now); !errors.Is(err, ErrExportJobNotFound) {
+		t.Errorf("Cancel of a missing job: err = %v, want ErrExportJobNotFound", err)
+	}
+	next, err := jobs.Claim(ctx, now, now.Add(-time.Hour), schedule)
+	if err != nil || next == nil || next.ID != queued[2].ID {
+		t.Errorf("Claim after the cancel = %v, %v; want the third job of the large organization", next, err)
+	}
+}
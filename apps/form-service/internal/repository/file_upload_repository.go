@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+)
+
+// FileUploadRepository defines the interface for file upload data operations
+type FileUploadRepository interface {
+	Create(ctx context.Context, upload *models.FileUpload) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.FileUpload, error)
+	GetByToken(ctx context.Context, token string) (*models.FileUpload, error)
+	Update(ctx context.Context, upload *models.FileUpload) error
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// ListPendingBefore returns uploads never attached to a response that were created before cutoff
+	ListPendingBefore(ctx context.Context, cutoff time.Time, limit int) ([]*models.FileUpload, error)
+	// ListAwaitingScan returns attached uploads that have not been scanned yet
+	ListAwaitingScan(ctx context.Context, limit int) ([]*models.FileUpload, error)
+	// ListAttached returns the uploads of a form attached to the responses
+	ListAttached(ctx context.Context, formID uuid.UUID, responseIDs []string) ([]*models.FileUpload, error)
+}
+
+// fileUploadRepository implements FileUploadRepository interface
+type fileUploadRepository struct {
+	db *gorm.DB
+}
+
+// NewFileUploadRepository creates a new file upload repository instance
+func NewFileUploadRepository(db *gorm.DB) FileUploadRepository {
+	return &fileUploadRepository{db: db}
+}
+
+// Create creates a new file upload record
+func (r *fileUploadRepository) Create(ctx context.Context, upload *models.FileUpload) error {
+	return r.db.WithContext(ctx).Create(upload).Error
+}
+
+// GetByID retrieves a file upload by its ID
+func (r *fileUploadRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.FileUpload, error) {
+	var upload models.FileUpload
+
+	err := r.db.WithContext(ctx).First(&upload, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &upload, nil
+}
+
+// GetByToken retrieves a file upload by its respondent-facing token
+func (r *fileUploadRepository) GetByToken(ctx context.Context, token string) (*models.FileUpload, error) {
+	var upload models.FileUpload
+
+	err := r.db.WithContext(ctx).First(&upload, "token = ?", token).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &upload, nil
+}
+
+// Update updates an existing file upload record
+func (r *fileUploadRepository) Update(ctx context.Context, upload *models.FileUpload) error {
+	return r.db.WithContext(ctx).Save(upload).Error
+}
+
+// Delete removes a file upload record
+func (r *fileUploadRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.FileUpload{}, "id = ?", id).Error
+}
+
+// ListPendingBefore returns orphaned uploads, oldest first
+func (r *fileUploadRepository) ListPendingBefore(ctx context.Context, cutoff time.Time, limit int) ([]*models.FileUpload, error) {
+	var uploads []*models.FileUpload
+
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND created_at < ?", models.FileUploadStatusPending, cutoff).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&uploads).Error
+
+	return uploads, err
+}
+
+// ListAwaitingScan returns attached uploads still waiting for a scan verdict, oldest first
+func (r *fileUploadRepository) ListAwaitingScan(ctx context.Context, limit int) ([]*models.FileUpload, error) {
+	var uploads []*models.FileUpload
+
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND scan_status = ?", models.FileUploadStatusAttached, models.FileScanStatusPending).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&uploads).Error
+
+	return uploads, err
+}
+
+// ListAttached returns the uploads attached to the responses, in upload order
+func (r *fileUploadRepository) ListAttached(ctx context.Context, formID uuid.UUID, responseIDs []string) ([]*models.FileUpload, error) {
+	var uploads []*models.FileUpload
+	if len(responseIDs) == 0 {
+		return uploads, nil
+	}
+
+	err := r.db.WithContext(ctx).
+		Where("form_id = ? AND status = ? AND response_id IN ?", formID, models.FileUploadStatusAttached, responseIDs).
+		Order("created_at ASC, id ASC").
+		Find(&uploads).Error
+
+	return uploads, err
+}
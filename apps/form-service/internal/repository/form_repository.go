@@ -4,29 +4,87 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/database"
 	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
 )
 
-// FormRepository defines the interface for form data operations
+// FormRepository defines the interface for form data operations. The
+// requests of users acting in an organization go through the
+// organization-scoped methods, which don't find the forms of other
+// organizations.
 type FormRepository interface {
 	// Form CRUD operations
 	Create(ctx context.Context, form *models.Form) error
+	// GetByID is not scoped to an organization; it serves respondents and
+	// background jobs, and users acting in no organization
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Form, error)
-	GetByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.Form, error)
 	Update(ctx context.Context, form *models.Form) error
 	Delete(ctx context.Context, id uuid.UUID) error
+	CountAll(ctx context.Context) (int64, error)
+
+	// Organization-scoped operations
+	GetInOrganization(ctx context.Context, orgID, id uuid.UUID) (*models.Form, error)
+	ListByOrganization(ctx context.Context, orgID, userID uuid.UUID, limit, offset int) ([]*models.Form, error)
+	CountByOrganization(ctx context.Context, orgID, userID uuid.UUID) (int64, error)
+	ListSharedInOrganization(ctx context.Context, orgID, userID uuid.UUID, limit, offset int) ([]*models.Form, error)
+	CountSharedInOrganization(ctx context.Context, orgID, userID uuid.UUID) (int64, error)
+	ListChangesInOrganization(ctx context.Context, orgID, userID uuid.UUID, after ChangeCursor, until time.Time, limit int) ([]*models.Form, error)
+
+	// Unscoped operations for users acting in no organization, kept until
+	// every token names one.
+	//
+	// Deprecated: use the organization-scoped operations.
+	GetByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.Form, error)
 	Count(ctx context.Context, userID uuid.UUID) (int64, error)
+	GetSharedWithUser(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.Form, error)
+	CountSharedWithUser(ctx context.Context, userID uuid.UUID) (int64, error)
+	ListChanges(ctx context.Context, userID uuid.UUID, after ChangeCursor, until time.Time, limit int) ([]*models.Form, error)
+
+	// Slugs. orgID uuid.Nil looks the slug up in every organization.
+	ListBySlug(ctx context.Context, orgID uuid.UUID, slug string) ([]*models.Form, error)
+	ListSlugRedirects(ctx context.Context, orgID uuid.UUID, slug string, now time.Time) ([]*models.FormSlugRedirect, error)
+	ChangeSlug(ctx context.Context, form *models.Form, previous string, redirectUntil time.Time) error
+
+	// ApplyMerge applies the changes of a merge computed against version of
+	// the form in one transaction, bumping the version once, and returns the
+	// new version. It returns ErrVersionChanged when the form changed since.
+	ApplyMerge(ctx context.Context, formID uuid.UUID, version int, merge FormMerge) (int, error)
+
+	// SetThrottled throttles the form at the time at, or releases it for a
+	// nil at, reporting false when it already was in that state
+	SetThrottled(ctx context.Context, id uuid.UUID, at *time.Time) (bool, error)
+
+	// ListCleanupCandidates returns up to limit forms after the cursor, in
+	// (created_at, id) order, matching the criteria of a cleanup except for
+	// ZeroResponses, which the analytics service answers
+	ListCleanupCandidates(ctx context.Context, criteria models.CleanupCriteria, after CleanupCursor, limit int) ([]*models.Form, error)
 
 	// Form access control
 	CanUserAccess(ctx context.Context, formID, userID uuid.UUID) (bool, error)
 	CanUserEdit(ctx context.Context, formID, userID uuid.UUID) (bool, error)
 }
 
+// ErrSlugTaken is returned when another form of the organization has the
+// slug, including when it claimed it concurrently
+var ErrSlugTaken = errors.New("slug is taken")
+
+// ErrVersionChanged is returned when a form changed since the version a merge
+// was computed against
+var ErrVersionChanged = errors.New("form version changed")
+
+// ErrQuestionKeyTaken is returned when another question of the form has the
+// key, including when it took it concurrently
+var ErrQuestionKeyTaken = errors.New("question key is taken")
+
 // QuestionRepository defines the interface for question data operations
 type QuestionRepository interface {
 	// Question CRUD operations
@@ -39,6 +97,10 @@ type QuestionRepository interface {
 	// Question ordering
 	UpdateOrder(ctx context.Context, formID uuid.UUID, questionOrders []QuestionOrder) error
 	GetMaxOrder(ctx context.Context, formID uuid.UUID) (int, error)
+
+	// Question keys
+	GetKeyAliases(ctx context.Context, formID uuid.UUID) ([]*models.QuestionKeyAlias, error)
+	RenameKey(ctx context.Context, question *models.Question, alias *models.QuestionKeyAlias) error
 }
 
 // CollaboratorRepository defines the interface for collaborator data operations
@@ -56,12 +118,36 @@ type CollaboratorRepository interface {
 	FindByFormAndUser(ctx context.Context, formID, userID uuid.UUID) (*models.Collaborator, error)
 }
 
+// ChangeCursor is the position of a form in the change feed, which is
+// ordered by (updated_at, id). The zero cursor is before every form.
+type ChangeCursor struct {
+	UpdatedAt time.Time
+	ID        uuid.UUID
+}
+
+// CleanupCursor is the position of a form in the walk of a cleanup, which
+// is ordered by (created_at, id). The zero cursor is before every form.
+type CleanupCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
 // QuestionOrder represents a question ordering request
 type QuestionOrder struct {
 	ID    uuid.UUID `json:"id"`
 	Order int       `json:"order"`
 }
 
+// FormMerge is what a merge changes in the definition of a form: the form
+// itself when Form is set, and its questions
+type FormMerge struct {
+	Form    *models.Form
+	Created []*models.Question
+	Updated []*models.Question
+	Deleted []uuid.UUID
+	Order   []QuestionOrder
+}
+
 // formRepository implements FormRepository interface
 type formRepository struct {
 	db *gorm.DB
@@ -75,54 +161,88 @@ func NewFormRepository(db *gorm.DB) FormRepository {
 // Create creates a new form in the database
 func (r *formRepository) Create(ctx context.Context, form *models.Form) error {
 	// Settings are handled in the BeforeCreate hook of the model
-	return r.db.WithContext(ctx).Create(form).Error
+	return slugError(inTransaction(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Create(form).Error
+	}))
 }
 
-// GetByID retrieves a form by its ID with all related data
+// GetByID retrieves a form by its ID with its computed fields. Form has no
+// association to preload: callers read the questions and collaborators of
+// the form through QuestionRepository.GetByFormID and
+// CollaboratorRepository.GetByFormID.
 func (r *formRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Form, error) {
 	var form models.Form
 
-	err := r.db.WithContext(ctx).
-		Preload("Questions", func(db *gorm.DB) *gorm.DB {
-			return db.Order("\"order\" ASC")
-		}).
-		Preload("Collaborators").
-		First(&form, "id = ?", id).Error
+	err := r.db.WithContext(ctx).First(&form, "id = ?", id).Error
 
 	if err != nil {
 		return nil, err
 	}
 
 	// Load computed fields
-	r.loadComputedFields(ctx, &form)
+	r.loadComputedFields(r.db.WithContext(ctx), &form)
 
 	return &form, nil
 }
 
-// GetByUserID retrieves forms for a specific user with pagination
+// GetInOrganization retrieves a form of an organization by its ID with its
+// computed fields. The forms of other organizations are not found.
+func (r *formRepository) GetInOrganization(ctx context.Context, orgID, id uuid.UUID) (*models.Form, error) {
+	var form models.Form
+
+	err := r.db.WithContext(ctx).First(&form, "id = ? AND organization_id = ?", id, orgID).Error
+	if err != nil {
+		return nil, err
+	}
+
+	r.loadComputedFields(r.db.WithContext(ctx), &form)
+
+	return &form, nil
+}
+
+// GetByUserID retrieves forms for a specific user with pagination, across
+// organizations
 func (r *formRepository) GetByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.Form, error) {
-	var forms []*models.Form
+	return r.list(r.reader(ctx), r.ownedBy(ctx, userID), limit, offset)
+}
 
-	query := r.db.WithContext(ctx).
-		Where("user_id = ?", userID).
-		Order("created_at DESC")
+// ListByOrganization retrieves the forms a user owns in an organization with
+// pagination
+func (r *formRepository) ListByOrganization(ctx context.Context, orgID, userID uuid.UUID, limit, offset int) ([]*models.Form, error) {
+	return r.list(r.reader(ctx), r.ownedBy(ctx, userID).Where("organization_id = ?", orgID), limit, offset)
+}
+
+// reader is the session of the reads that may be served by a replica: the
+// lists, counts and change feeds of forms
+func (r *formRepository) reader(ctx context.Context) *gorm.DB {
+	return database.Replica(r.db.WithContext(ctx))
+}
 
+// ownedBy selects the forms of a user, from a replica
+func (r *formRepository) ownedBy(ctx context.Context, userID uuid.UUID) *gorm.DB {
+	return r.reader(ctx).Where("user_id = ?", userID)
+}
+
+// list retrieves a page of the forms query selects, newest first, with their
+// computed fields read from db
+func (r *formRepository) list(db, query *gorm.DB, limit, offset int) ([]*models.Form, error) {
+	var forms []*models.Form
+
+	query = query.Order("created_at DESC")
 	if limit > 0 {
 		query = query.Limit(limit)
 	}
-
 	if offset > 0 {
 		query = query.Offset(offset)
 	}
 
-	err := query.Find(&forms).Error
-	if err != nil {
+	if err := query.Find(&forms).Error; err != nil {
 		return nil, err
 	}
 
 	// Load computed fields for each form
 	for _, form := range forms {
-		r.loadComputedFields(ctx, form)
+		r.loadComputedFields(db, form)
 	}
 
 	return forms, nil
@@ -130,25 +250,315 @@ func (r *formRepository) GetByUserID(ctx context.Context, userID uuid.UUID, limi
 
 // Update updates an existing form
 func (r *formRepository) Update(ctx context.Context, form *models.Form) error {
-	return r.db.WithContext(ctx).Save(form).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := saveForm(tx, form); err != nil {
+			return err
+		}
+		return recordActivity(ctx, tx)
+	})
+}
+
+// saveForm saves a form and bumps its version, setting form.Version to the
+// new one
+func saveForm(tx *gorm.DB, form *models.Form) error {
+	if err := tx.Omit("version").Save(form).Error; err != nil {
+		return err
+	}
+	return tx.Raw(`UPDATE "forms" SET "version" = "version" + 1 WHERE "id" = ? RETURNING "version"`, form.ID).
+		Scan(&form.Version).Error
 }
 
-// Delete soft deletes a form
+// Delete soft deletes a form. updated_at is bumped with deleted_at, so the
+// deletion shows in the change feed.
 func (r *formRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	return r.db.WithContext(ctx).Delete(&models.Form{}, "id = ?", id).Error
+	now := time.Now()
+	return r.db.WithContext(ctx).
+		Model(&models.Form{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{"deleted_at": now, "updated_at": now}).Error
 }
 
-// Count returns the total number of forms for a user
+// Count returns the total number of forms for a user, across organizations
 func (r *formRepository) Count(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.ownedBy(ctx, userID).
+		Model(&models.Form{}).
+		Count(&count).Error
+
+	return count, err
+}
+
+// CountByOrganization returns the number of forms a user owns in an
+// organization
+func (r *formRepository) CountByOrganization(ctx context.Context, orgID, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.ownedBy(ctx, userID).
+		Model(&models.Form{}).
+		Where("organization_id = ?", orgID).
+		Count(&count).Error
+
+	return count, err
+}
+
+// CountAll returns the total number of forms across all users
+func (r *formRepository) CountAll(ctx context.Context) (int64, error) {
 	var count int64
 	err := r.db.WithContext(ctx).
 		Model(&models.Form{}).
-		Where("user_id = ?", userID).
 		Count(&count).Error
 
 	return count, err
 }
 
+// GetSharedWithUser retrieves the forms a user is an active collaborator on,
+// across organizations
+func (r *formRepository) GetSharedWithUser(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.Form, error) {
+	return r.list(r.reader(ctx), r.sharedWith(ctx, userID), limit, offset)
+}
+
+// ListSharedInOrganization retrieves the forms of an organization a user is
+// an active collaborator on
+func (r *formRepository) ListSharedInOrganization(ctx context.Context, orgID, userID uuid.UUID, limit, offset int) ([]*models.Form, error) {
+	return r.list(r.reader(ctx), r.sharedWith(ctx, userID).Where("organization_id = ?", orgID), limit, offset)
+}
+
+// CountSharedWithUser returns the number of forms shared with a user, across
+// organizations
+func (r *formRepository) CountSharedWithUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.sharedWith(ctx, userID).
+		Model(&models.Form{}).
+		Count(&count).Error
+
+	return count, err
+}
+
+// CountSharedInOrganization returns the number of forms of an organization
+// shared with a user
+func (r *formRepository) CountSharedInOrganization(ctx context.Context, orgID, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.sharedWith(ctx, userID).
+		Model(&models.Form{}).
+		Where("organization_id = ?", orgID).
+		Count(&count).Error
+
+	return count, err
+}
+
+// sharedWith selects the forms a user is an active collaborator on, from a
+// replica
+func (r *formRepository) sharedWith(ctx context.Context, userID uuid.UUID) *gorm.DB {
+	collaborations := r.db.WithContext(ctx).
+		Model(&models.Collaborator{}).
+		Select("form_id").
+		Where("user_id = ? AND status = ?", userID, models.CollaboratorStatusActive)
+
+	return r.reader(ctx).
+		Where("id IN (?) AND user_id <> ?", collaborations, userID)
+}
+
+// ListChanges returns up to limit forms of a user, deleted forms included,
+// changed after the cursor and no later than until, ordered by (updated_at,
+// id), across organizations
+func (r *formRepository) ListChanges(ctx context.Context, userID uuid.UUID, after ChangeCursor, until time.Time, limit int) ([]*models.Form, error) {
+	return r.changes(r.ownedBy(ctx, userID), after, until, limit)
+}
+
+// ListChangesInOrganization returns the changes of ListChanges of the forms
+// a user owns in an organization
+func (r *formRepository) ListChangesInOrganization(ctx context.Context, orgID, userID uuid.UUID, after ChangeCursor, until time.Time, limit int) ([]*models.Form, error) {
+	return r.changes(r.ownedBy(ctx, userID).Where("organization_id = ?", orgID), after, until, limit)
+}
+
+// changes returns the changes of the forms query selects
+func (r *formRepository) changes(query *gorm.DB, after ChangeCursor, until time.Time, limit int) ([]*models.Form, error) {
+	var forms []*models.Form
+
+	query = query.
+		Unscoped().
+		Where("updated_at <= ?", until)
+	if !after.UpdatedAt.IsZero() {
+		query = query.Where("(updated_at, id) > (?, ?)", after.UpdatedAt, after.ID)
+	}
+
+	err := query.
+		Order("updated_at ASC, id ASC").
+		Limit(limit).
+		Find(&forms).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return forms, nil
+}
+
+// ListBySlug retrieves the forms having a slug, in an organization or in
+// every organization for orgID uuid.Nil
+func (r *formRepository) ListBySlug(ctx context.Context, orgID uuid.UUID, slug string) ([]*models.Form, error) {
+	query := r.db.WithContext(ctx).Where("slug = ?", slug)
+	if orgID != uuid.Nil {
+		query = query.Where("organization_id = ?", orgID)
+	}
+	return r.list(r.db.WithContext(ctx), query, 0, 0)
+}
+
+// ListSlugRedirects retrieves the redirects of a slug that have not expired
+// by now, in an organization or in every organization for orgID uuid.Nil
+func (r *formRepository) ListSlugRedirects(ctx context.Context, orgID uuid.UUID, slug string, now time.Time) ([]*models.FormSlugRedirect, error) {
+	query := r.db.WithContext(ctx).Where("slug = ? AND expires_at > ?", slug, now)
+	if orgID != uuid.Nil {
+		query = query.Where("organization_id = ?", orgID)
+	}
+
+	var redirects []*models.FormSlugRedirect
+	if err := query.Find(&redirects).Error; err != nil {
+		return nil, err
+	}
+	return redirects, nil
+}
+
+// SetThrottled sets throttled_at when it is unset, or clears it for a nil
+// at, without saving the rest of the form
+func (r *formRepository) SetThrottled(ctx context.Context, id uuid.UUID, at *time.Time) (bool, error) {
+	query := "UPDATE forms SET throttled_at = ? WHERE id = ? AND deleted_at IS NULL AND throttled_at IS NULL"
+	if at == nil {
+		query = "UPDATE forms SET throttled_at = ? WHERE id = ? AND deleted_at IS NULL AND throttled_at IS NOT NULL"
+	}
+	result := r.db.WithContext(ctx).Exec(query, at, id)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ListCleanupCandidates returns the forms a cleanup may archive
+func (r *formRepository) ListCleanupCandidates(ctx context.Context, criteria models.CleanupCriteria, after CleanupCursor, limit int) ([]*models.Form, error) {
+	query := r.db.WithContext(ctx)
+	if criteria.InactiveBefore != nil {
+		query = query.Where("updated_at < ?", *criteria.InactiveBefore)
+	}
+	if criteria.OwnerID != nil {
+		query = query.Where("user_id = ?", *criteria.OwnerID)
+	}
+	if criteria.OrganizationID != nil {
+		query = query.Where("organization_id = ?", *criteria.OrganizationID)
+	}
+	if criteria.Status != "" {
+		query = query.Where("status = ?", criteria.Status)
+	}
+	if !after.CreatedAt.IsZero() {
+		query = query.Where("(created_at, id) > (?, ?)", after.CreatedAt, after.ID)
+	}
+
+	var forms []*models.Form
+	err := query.
+		Order("created_at ASC, id ASC").
+		Limit(limit).
+		Find(&forms).Error
+	if err != nil {
+		return nil, err
+	}
+	return forms, nil
+}
+
+// ChangeSlug saves a form whose slug changed from previous. previous, unless
+// empty, redirects to the form until redirectUntil; a redirect of the new
+// slug is dropped since the form now has it.
+func (r *formRepository) ChangeSlug(ctx context.Context, form *models.Form, previous string, redirectUntil time.Time) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := saveForm(tx, form); err != nil {
+			return err
+		}
+		if form.Slug != nil {
+			err := tx.Where("organization_id = ? AND slug = ?", form.OrganizationID, *form.Slug).
+				Delete(&models.FormSlugRedirect{}).Error
+			if err != nil {
+				return err
+			}
+		}
+		if previous != "" {
+			redirect := &models.FormSlugRedirect{
+				OrganizationID: form.OrganizationID,
+				Slug:           previous,
+				FormID:         form.ID,
+				ExpiresAt:      redirectUntil,
+			}
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "organization_id"}, {Name: "slug"}},
+				DoUpdates: clause.AssignmentColumns([]string{"form_id", "expires_at", "created_at"}),
+			}).Create(redirect).Error
+			if err != nil {
+				return err
+			}
+		}
+		return recordActivity(ctx, tx)
+	})
+	return slugError(err)
+}
+
+// ApplyMerge applies the changes of a merge in one transaction. The version
+// is claimed first, which locks the form until the merge commits.
+func (r *formRepository) ApplyMerge(ctx context.Context, formID uuid.UUID, version int, merge FormMerge) (int, error) {
+	var next int
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		claim := tx.Raw(`UPDATE "forms" SET "version" = "version" + 1, "updated_at" = ?
+			WHERE "id" = ? AND "version" = ? AND "deleted_at" IS NULL RETURNING "version"`, time.Now(), formID, version).
+			Scan(&next)
+		if claim.Error != nil {
+			return claim.Error
+		}
+		if claim.RowsAffected == 0 {
+			return ErrVersionChanged
+		}
+
+		if merge.Form != nil {
+			merge.Form.Version = next
+			if err := tx.Save(merge.Form).Error; err != nil {
+				return err
+			}
+		}
+		// Questions are deleted first, so that those added may take their keys
+		if len(merge.Deleted) > 0 {
+			err := tx.Where("form_id = ? AND id IN ?", formID, merge.Deleted).Delete(&models.Question{}).Error
+			if err != nil {
+				return err
+			}
+		}
+		for _, question := range merge.Created {
+			if err := tx.Create(question).Error; err != nil {
+				return err
+			}
+		}
+		for _, question := range merge.Updated {
+			if err := tx.Omit("Form").Save(question).Error; err != nil {
+				return err
+			}
+		}
+		for _, qo := range merge.Order {
+			err := tx.Model(&models.Question{}).
+				Where("id = ? AND form_id = ?", qo.ID, formID).
+				Update("order", qo.Order).Error
+			if err != nil {
+				return err
+			}
+		}
+		return recordActivity(ctx, tx)
+	})
+	if err != nil {
+		return 0, questionKeyError(err)
+	}
+	return next, nil
+}
+
+// slugError reports violations of the unique slug index as ErrSlugTaken
+func slugError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_forms_organization_slug" {
+		return ErrSlugTaken
+	}
+	return err
+}
+
 // CanUserAccess checks if a user can access a form (view permission)
 func (r *formRepository) CanUserAccess(ctx context.Context, formID, userID uuid.UUID) (bool, error) {
 	var count int64
@@ -170,7 +580,7 @@ func (r *formRepository) CanUserAccess(ctx context.Context, formID, userID uuid.
 	// Check if user is a collaborator
 	err = r.db.WithContext(ctx).
 		Model(&models.Collaborator{}).
-		Where("form_id = ? AND user_id = ?", formID, userID).
+		Where("form_id = ? AND user_id = ? AND status = ?", formID, userID, models.CollaboratorStatusActive).
 		Count(&count).Error
 
 	if err != nil {
@@ -201,8 +611,9 @@ func (r *formRepository) CanUserEdit(ctx context.Context, formID, userID uuid.UU
 	// Check if user is a collaborator with edit permissions
 	err = r.db.WithContext(ctx).
 		Model(&models.Collaborator{}).
-		Where("form_id = ? AND user_id = ? AND role IN (?)",
-			formID, userID, []string{"owner", "editor"}).
+		Where("form_id = ? AND user_id = ? AND status = ? AND role IN (?)",
+			formID, userID, models.CollaboratorStatusActive,
+			[]models.CollaboratorRole{models.CollaboratorRoleEditor, models.CollaboratorRoleManager}).
 		Count(&count).Error
 
 	if err != nil {
@@ -212,11 +623,11 @@ func (r *formRepository) CanUserEdit(ctx context.Context, formID, userID uuid.UU
 	return count > 0, nil
 }
 
-// loadComputedFields loads computed fields for a form
-func (r *formRepository) loadComputedFields(ctx context.Context, form *models.Form) {
+// loadComputedFields loads computed fields for a form from db
+func (r *formRepository) loadComputedFields(db *gorm.DB, form *models.Form) {
 	// Load question count
 	var questionCount int64
-	r.db.WithContext(ctx).
+	db.
 		Model(&models.Question{}).
 		Where("form_id = ?", form.ID).
 		Count(&questionCount)
@@ -224,7 +635,7 @@ func (r *formRepository) loadComputedFields(ctx context.Context, form *models.Fo
 
 	// Load collaborator count
 	var collaboratorCount int64
-	r.db.WithContext(ctx).
+	db.
 		Model(&models.Collaborator{}).
 		Where("form_id = ?", form.ID).
 		Count(&collaboratorCount)
@@ -255,7 +666,16 @@ func (r *questionRepository) Create(ctx context.Context, question *models.Questi
 		question.Order = maxOrder + 1
 	}
 
-	return r.db.WithContext(ctx).Create(question).Error
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(question).Error; err != nil {
+			return err
+		}
+		if err := touchForm(tx, question.FormID); err != nil {
+			return err
+		}
+		return recordActivity(ctx, tx)
+	})
+	return questionKeyError(err)
 }
 
 // GetByID retrieves a question by its ID
@@ -291,12 +711,32 @@ func (r *questionRepository) GetByFormID(ctx context.Context, formID uuid.UUID)
 
 // Update updates an existing question
 func (r *questionRepository) Update(ctx context.Context, question *models.Question) error {
-	return r.db.WithContext(ctx).Save(question).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(question).Error; err != nil {
+			return err
+		}
+		if err := touchForm(tx, question.FormID); err != nil {
+			return err
+		}
+		return recordActivity(ctx, tx)
+	})
 }
 
 // Delete soft deletes a question
 func (r *questionRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	return r.db.WithContext(ctx).Delete(&models.Question{}, "id = ?", id).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var question models.Question
+		if err := tx.Select("id", "form_id").First(&question, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.Question{}, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := touchForm(tx, question.FormID); err != nil {
+			return err
+		}
+		return recordActivity(ctx, tx)
+	})
 }
 
 // UpdateOrder updates the order of multiple questions in a transaction
@@ -311,10 +751,73 @@ func (r *questionRepository) UpdateOrder(ctx context.Context, formID uuid.UUID,
 				return err
 			}
 		}
-		return nil
+		if err := touchForm(tx, formID); err != nil {
+			return err
+		}
+		return recordActivity(ctx, tx)
 	})
 }
 
+// GetKeyAliases lists the keys the questions of a form were renamed from
+func (r *questionRepository) GetKeyAliases(ctx context.Context, formID uuid.UUID) ([]*models.QuestionKeyAlias, error) {
+	var aliases []*models.QuestionKeyAlias
+	err := r.db.WithContext(ctx).
+		Where("form_id = ?", formID).
+		Order("created_at ASC").
+		Find(&aliases).Error
+	if err != nil {
+		return nil, err
+	}
+	return aliases, nil
+}
+
+// RenameKey saves the new key of a question and records the key it was
+// renamed from as alias. The aliases the keys had are dropped: those of the
+// question, when it is renamed back to one of its former keys, and those of
+// deleted questions.
+func (r *questionRepository) RenameKey(ctx context.Context, question *models.Question, alias *models.QuestionKeyAlias) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("form_id = ? AND key IN ?", question.FormID, []string{question.Key, alias.Key}).
+			Delete(&models.QuestionKeyAlias{}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(alias).Error; err != nil {
+			return err
+		}
+		err = tx.Model(&models.Question{}).
+			Where("id = ?", question.ID).
+			Updates(map[string]interface{}{"key": question.Key, "updated_at": time.Now()}).Error
+		if err != nil {
+			return err
+		}
+		if err := touchForm(tx, question.FormID); err != nil {
+			return err
+		}
+		return recordActivity(ctx, tx)
+	})
+	return questionKeyError(err)
+}
+
+// questionKeyError reports violations of the unique question key index, and
+// of the primary key of the aliases, as ErrQuestionKeyTaken
+func questionKeyError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" &&
+		(pgErr.ConstraintName == "idx_questions_form_key" || pgErr.ConstraintName == "question_key_aliases_pkey") {
+		return ErrQuestionKeyTaken
+	}
+	return err
+}
+
+// touchForm bumps updated_at and the version of a form whose questions
+// changed, so the change shows in the change feed and to merges
+func touchForm(tx *gorm.DB, formID uuid.UUID) error {
+	return tx.Model(&models.Form{}).
+		Where("id = ?", formID).
+		UpdateColumns(map[string]interface{}{"updated_at": time.Now(), "version": gorm.Expr(`"version" + 1`)}).Error
+}
+
 // GetMaxOrder returns the maximum order value for questions in a form
 func (r *questionRepository) GetMaxOrder(ctx context.Context, formID uuid.UUID) (int, error) {
 	var maxOrder int
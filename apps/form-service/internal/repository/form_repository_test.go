@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/database"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+)
+
+// testDB migrates a new schema of FORM_SERVICE_TEST_DATABASE_URL, dropped
+// when the test ends
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("FORM_SERVICE_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("FORM_SERVICE_TEST_DATABASE_URL not set")
+	}
+
+	admin, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := fmt.Sprintf("repository_test_%d", time.Now().UnixNano())
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		sqlDB, _ := admin.DB()
+		sqlDB.Close()
+	})
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+
+	db, err := gorm.Open(postgres.Open(u.String()), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := database.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// personalOrganization returns the ID of the personal organization of a user
+func personalOrganization(t *testing.T, db *gorm.DB, userID uuid.UUID) uuid.UUID {
+	t.Helper()
+	org, err := NewOrganizationRepository(db).Personal(context.Background(), userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return org.ID
+}
+
+// TestGetByIDPreloadsNothing guards GetByID against preloading associations
+// Form doesn't declare, which fails every lookup with "unsupported relations"
+// before the database is queried, so it needs no database
+func TestGetByIDPreloadsNothing(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 port=1"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFormRepository(db).GetByID(context.Background(), uuid.New()); err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+}
+
+// TestListChanges checks that creating a form, changing its questions and
+// deleting it each move it to the end of the change feed
+func TestListChanges(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	forms, questions := NewFormRepository(db), NewQuestionRepository(db)
+	owner := uuid.New()
+
+	changesAfter := func(after ChangeCursor) []*models.Form {
+		t.Helper()
+		changed, err := forms.ListChanges(ctx, owner, after, time.Now().Add(time.Minute), 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return changed
+	}
+	last := func(changed []*models.Form) ChangeCursor {
+		t.Helper()
+		if len(changed) == 0 {
+			t.Fatal("no change")
+		}
+		return ChangeCursor{UpdatedAt: changed[len(changed)-1].UpdatedAt, ID: changed[len(changed)-1].ID}
+	}
+
+	orgID := personalOrganization(t, db, owner)
+	form := &models.Form{UserID: owner, OrganizationID: orgID, Title: "Feedback"}
+	other := &models.Form{UserID: owner, OrganizationID: orgID, Title: "Other"}
+	for _, f := range []*models.Form{form, other} {
+		if err := forms.Create(ctx, f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	changed := changesAfter(ChangeCursor{})
+	if len(changed) != 2 {
+		t.Fatalf("changes = %v, want both forms", changed)
+	}
+	cursor := last(changed)
+
+	question := &models.Question{FormID: form.ID, Type: models.QuestionTypeText, Title: "Why?"}
+	if err := questions.Create(ctx, question); err != nil {
+		t.Fatal(err)
+	}
+	changed = changesAfter(cursor)
+	if len(changed) != 1 || changed[0].ID != form.ID {
+		t.Fatalf("changes after adding a question = %v, want the form", changed)
+	}
+	cursor = last(changed)
+
+	question.Title = "Why not?"
+	if err := questions.Update(ctx, question); err != nil {
+		t.Fatal(err)
+	}
+	if changed = changesAfter(cursor); len(changed) != 1 || changed[0].ID != form.ID {
+		t.Fatalf("changes after updating a question = %v, want the form", changed)
+	}
+	cursor = last(changed)
+
+	if err := questions.Delete(ctx, question.ID); err != nil {
+		t.Fatal(err)
+	}
+	if changed = changesAfter(cursor); len(changed) != 1 || changed[0].ID != form.ID {
+		t.Fatalf("changes after deleting a question = %v, want the form", changed)
+	}
+	cursor = last(changed)
+
+	if err := forms.Delete(ctx, form.ID); err != nil {
+		t.Fatal(err)
+	}
+	changed = changesAfter(cursor)
+	if len(changed) != 1 || changed[0].ID != form.ID || !changed[0].DeletedAt.Valid {
+		t.Fatalf("changes after deleting the form = %v, want its tombstone", changed)
+	}
+	if changed = changesAfter(last(changed)); len(changed) != 0 {
+		t.Errorf("changes at the end of the feed = %v", changed)
+	}
+}
+
+// TestApplyMerge checks that a merge bumps the version once and is rejected
+// once the form changed since the version it was computed against
+func TestApplyMerge(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	forms, questions := NewFormRepository(db), NewQuestionRepository(db)
+	owner := uuid.New()
+
+	form := &models.Form{UserID: owner, OrganizationID: personalOrganization(t, db, owner), Title: "Feedback"}
+	if err := forms.Create(ctx, form); err != nil {
+		t.Fatal(err)
+	}
+	first := &models.Question{FormID: form.ID, Type: models.QuestionTypeText, Title: "Why?", Order: 1}
+	second := &models.Question{FormID: form.ID, Type: models.QuestionTypeText, Title: "Why not?", Order: 2}
+	for _, q := range []*models.Question{first, second} {
+		if err := questions.Create(ctx, q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	current, err := forms.GetByID(ctx, form.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.Version != 3 {
+		t.Fatalf("version after adding 2 questions = %d, want 3", current.Version)
+	}
+
+	current.Title = "Product feedback"
+	first.Title = "Why so?"
+	added := &models.Question{ID: uuid.New(), FormID: form.ID, Type: models.QuestionTypeEmail, Title: "Email", Order: 1}
+	version, err := forms.ApplyMerge(ctx, form.ID, 3, FormMerge{
+		Form:    current,
+		Created: []*models.Question{added},
+		Updated: []*models.Question{first},
+		Deleted: []uuid.UUID{second.ID},
+		Order:   []QuestionOrder{{ID: first.ID, Order: 2}},
+	})
+	if err != nil {
+		t.Fatalf("ApplyMerge: %v", err)
+	}
+	if version != 4 {
+		t.Errorf("version = %d, want 4", version)
+	}
+
+	merged, _ := forms.GetByID(ctx, form.ID)
+	remaining, _ := questions.GetByFormID(ctx, form.ID)
+	if merged.Title != "Product feedback" || merged.Version != 4 {
+		t.Errorf("form = %q at version %d, want the merged title at 4", merged.Title, merged.Version)
+	}
+	if len(remaining) != 2 || remaining[0].ID != added.ID || remaining[1].Title != "Why so?" || remaining[1].Order != 2 {
+		t.Errorf("questions = %+v, want the added question then the updated one", remaining)
+	}
+
+	if _, err := forms.ApplyMerge(ctx, form.ID, 3, FormMerge{Form: merged}); !errors.Is(err, ErrVersionChanged) {
+		t.Errorf("merging against a past version: err = %v, want ErrVersionChanged", err)
+	}
+	merged.Title = "Renamed"
+	if err := forms.Update(ctx, merged); err != nil || merged.Version != 5 {
+		t.Errorf("Update = %v, version %d; want the version bumped to 5", err, merged.Version)
+	}
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// FunnelBeacons deduplicates and rate limits the funnel beacons of public
+// forms
+type FunnelBeacons interface {
+	// Record records the stage of a session of the form for ttl, reporting
+	// false when it was recorded already
+	Record(ctx context.Context, formID uuid.UUID, session, stage string, ttl time.Duration) (bool, error)
+	// Count counts a beacon of client to the form in the current window and
+	// returns the beacons of client counted in it so far
+	Count(ctx context.Context, formID uuid.UUID, client string, window time.Duration) (int64, error)
+}
+
+// redisFunnelBeacons keeps a key per session and stage recorded, and a
+// counter per client, form and window
+type redisFunnelBeacons struct {
+	client *redis.Client
+}
+
+// NewRedisFunnelBeacons creates funnel beacons backed by Redis
+func NewRedisFunnelBeacons(client *redis.Client) FunnelBeacons {
+	return &redisFunnelBeacons{client: client}
+}
+
+// Record takes the key of the session and stage with SET NX
+func (r *redisFunnelBeacons) Record(ctx context.Context, formID uuid.UUID, session, stage string, ttl time.Duration) (bool, error) {
+	recorded, err := r.client.SetNX(ctx, fmt.Sprintf("form-service:funnel:%s:%s:%s", formID, session, stage), 1, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to record funnel stage: %w", err)
+	}
+	return recorded, nil
+}
+
+// Count increments the counter of the fixed window the current time falls
+// in, which expires with the window
+func (r *redisFunnelBeacons) Count(ctx context.Context, formID uuid.UUID, client string, window time.Duration) (int64, error) {
+	slot := time.Now().UnixNano() / int64(window)
+	key := fmt.Sprintf("form-service:funnel-rate:%s:%s:%d", formID, client, slot)
+
+	var count *redis.IntCmd
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		count = pipe.Incr(ctx, key)
+		pipe.Expire(ctx, key, window)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count funnel beacon: %w", err)
+	}
+	return count.Val(), nil
+}
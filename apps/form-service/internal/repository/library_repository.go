@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+)
+
+// ErrLibraryQuestionNotFound is returned for library questions that don't
+// exist in the organization
+var ErrLibraryQuestionNotFound = errors.New("library question not found")
+
+// LibrarySearch filters the library questions of an organization
+type LibrarySearch struct {
+	// Query matches the title or description, case insensitively
+	Query string
+	// Tags are all required of a question
+	Tags []string
+	Type models.QuestionType
+}
+
+// LibraryRepository defines the interface for the question bank of
+// organizations and the form questions copied from it
+type LibraryRepository interface {
+	Create(ctx context.Context, question *models.LibraryQuestion) error
+	Get(ctx context.Context, orgID, id uuid.UUID) (*models.LibraryQuestion, error)
+	Update(ctx context.Context, question *models.LibraryQuestion) error
+	Delete(ctx context.Context, orgID, id uuid.UUID) error
+	Search(ctx context.Context, orgID uuid.UUID, search LibrarySearch, offset, limit int) ([]*models.LibraryQuestion, int64, error)
+
+	// CopyIntoForm inserts the copies into the form starting at order
+	// position, moving the questions from that position down
+	CopyIntoForm(ctx context.Context, formID uuid.UUID, position int, copies []*models.Question) error
+	// Usages lists the form questions copied from an older version of the
+	// library question
+	Usages(ctx context.Context, question *models.LibraryQuestion) ([]models.LibraryQuestionUsage, error)
+	// Propagate updates the copies of older versions of the library question
+	// in draft forms to its definition, returning the forms updated. Copies
+	// in published and closed forms are left as they are.
+	Propagate(ctx context.Context, question *models.LibraryQuestion) ([]uuid.UUID, error)
+}
+
+// libraryRepository implements LibraryRepository interface
+type libraryRepository struct {
+	db *gorm.DB
+}
+
+// NewLibraryRepository creates a new library repository instance
+func NewLibraryRepository(db *gorm.DB) LibraryRepository {
+	return &libraryRepository{db: db}
+}
+
+// Create creates a new library question
+func (r *libraryRepository) Create(ctx context.Context, question *models.LibraryQuestion) error {
+	return r.db.WithContext(ctx).Create(question).Error
+}
+
+// Get retrieves a library question of the organization
+func (r *libraryRepository) Get(ctx context.Context, orgID, id uuid.UUID) (*models.LibraryQuestion, error) {
+	var question models.LibraryQuestion
+
+	err := r.db.WithContext(ctx).
+		First(&question, "id = ? AND organization_id = ?", id, orgID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrLibraryQuestionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &question, nil
+}
+
+// Update saves the definition and tags of a library question
+func (r *libraryRepository) Update(ctx context.Context, question *models.LibraryQuestion) error {
+	return r.db.WithContext(ctx).Save(question).Error
+}
+
+// Delete soft deletes a library question. Its copies stay in their forms.
+func (r *libraryRepository) Delete(ctx context.Context, orgID, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).
+		Delete(&models.LibraryQuestion{}, "id = ? AND organization_id = ?", id, orgID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrLibraryQuestionNotFound
+	}
+	return nil
+}
+
+// Search lists the library questions of the organization matching search,
+// most recently updated first, with the number matching
+func (r *libraryRepository) Search(ctx context.Context, orgID uuid.UUID, search LibrarySearch, offset, limit int) ([]*models.LibraryQuestion, int64, error) {
+	query := r.db.WithContext(ctx).
+		Model(&models.LibraryQuestion{}).
+		Where("organization_id = ?", orgID)
+
+	if q := strings.TrimSpace(search.Query); q != "" {
+		pattern := "%" + escapeLike(q) + "%"
+		query = query.Where("(title ILIKE ? OR description ILIKE ?)", pattern, pattern)
+	}
+	if len(search.Tags) > 0 {
+		tags, err := json.Marshal(search.Tags)
+		if err != nil {
+			return nil, 0, err
+		}
+		query = query.Where("tags @> ?::jsonb", string(tags))
+	}
+	if search.Type != "" {
+		query = query.Where("type = ?", search.Type)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var questions []*models.LibraryQuestion
+	err := query.
+		Order("updated_at DESC, id").
+		Offset(offset).
+		Limit(limit).
+		Find(&questions).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return questions, total, nil
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// CopyIntoForm inserts the copies into the form in a transaction
+func (r *libraryRepository) CopyIntoForm(ctx context.Context, formID uuid.UUID, position int, copies []*models.Question) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Question{}).
+			Where("form_id = ? AND \"order\" >= ?", formID, position).
+			UpdateColumn("order", gorm.Expr("\"order\" + ?", len(copies))).Error
+		if err != nil {
+			return err
+		}
+
+		for i, question := range copies {
+			question.FormID = formID
+			question.Order = position + i
+			if err := tx.Create(question).Error; err != nil {
+				return err
+			}
+		}
+		return touchForm(tx, formID)
+	})
+	return questionKeyError(err)
+}
+
+// Usages lists the copies of older versions in forms that were not deleted
+func (r *libraryRepository) Usages(ctx context.Context, question *models.LibraryQuestion) ([]models.LibraryQuestionUsage, error) {
+	usages := []models.LibraryQuestionUsage{}
+
+	err := r.db.WithContext(ctx).
+		Table("questions q").
+		Select("f.id AS form_id, f.title AS form_title, f.status AS form_status, q.id AS question_id, q.library_version").
+		Joins("JOIN forms f ON f.id = q.form_id AND f.deleted_at IS NULL").
+		Where("q.library_question_id = ? AND q.library_version < ? AND q.deleted_at IS NULL", question.ID, question.Version).
+		Order("f.id, q.\"order\"").
+		Scan(&usages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return usages, nil
+}
+
+// Propagate updates the copies in draft forms in a transaction, keeping
+// their order
+func (r *libraryRepository) Propagate(ctx context.Context, question *models.LibraryQuestion) ([]uuid.UUID, error) {
+	var formIDs []uuid.UUID
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The copies are validated as the library question was; the update
+		// skips the hooks
+		err := tx.Raw(`WITH updated AS (
+			UPDATE questions q
+			SET type = ?, title = ?, description = ?, options = ?, validation = ?, library_version = ?, updated_at = ?
+			FROM forms f
+			WHERE f.id = q.form_id AND f.status = ? AND f.deleted_at IS NULL
+				AND q.library_question_id = ? AND q.library_version < ? AND q.deleted_at IS NULL
+			RETURNING q.form_id
+		)
+		SELECT DISTINCT form_id FROM updated`,
+			question.Type, question.Title, question.Description, question.Options, question.Validation,
+			question.Version, time.Now(),
+			models.FormStatusDraft, question.ID, question.Version,
+		).Scan(&formIDs).Error
+		if err != nil {
+			return fmt.Errorf("failed to update copies: %w", err)
+		}
+
+		for _, formID := range formIDs {
+			if err := touchForm(tx, formID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return formIDs, nil
+}
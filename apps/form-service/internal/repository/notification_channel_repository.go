@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+)
+
+var (
+	// ErrChannelNotFound is returned for notification channels the form
+	// does not have
+	ErrChannelNotFound = errors.New("notification channel not found")
+	// ErrTooManyChannels is returned when a form already has the most
+	// notification channels allowed
+	ErrTooManyChannels = errors.New("the form has too many notification channels")
+)
+
+// NotificationChannelRepository defines the interface for the Slack and
+// Teams channels of forms
+type NotificationChannelRepository interface {
+	// Create adds a channel to its form unless the form already has max
+	// channels
+	Create(ctx context.Context, channel *models.NotificationChannel, max int) error
+	Get(ctx context.Context, formID, id uuid.UUID) (*models.NotificationChannel, error)
+	// ListByForm lists the channels of the form, oldest first
+	ListByForm(ctx context.Context, formID uuid.UUID) ([]*models.NotificationChannel, error)
+	Update(ctx context.Context, channel *models.NotificationChannel) error
+	Delete(ctx context.Context, formID, id uuid.UUID) error
+}
+
+// notificationChannelRepository implements NotificationChannelRepository interface
+type notificationChannelRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationChannelRepository creates a new notification channel repository instance
+func NewNotificationChannelRepository(db *gorm.DB) NotificationChannelRepository {
+	return &notificationChannelRepository{db: db}
+}
+
+// Create counts the channels of the form with the form row locked, so that
+// concurrent creations can't exceed max
+func (r *notificationChannelRepository) Create(ctx context.Context, channel *models.NotificationChannel, max int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var form models.Form
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&form, "id = ?", channel.FormID).Error
+		if err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&models.NotificationChannel{}).Where("form_id = ?", channel.FormID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= int64(max) {
+			return ErrTooManyChannels
+		}
+		return tx.Create(channel).Error
+	})
+}
+
+// Get retrieves a channel of the form
+func (r *notificationChannelRepository) Get(ctx context.Context, formID, id uuid.UUID) (*models.NotificationChannel, error) {
+	var channel models.NotificationChannel
+
+	err := r.db.WithContext(ctx).First(&channel, "id = ? AND form_id = ?", id, formID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrChannelNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &channel, nil
+}
+
+// ListByForm lists the channels of the form
+func (r *notificationChannelRepository) ListByForm(ctx context.Context, formID uuid.UUID) ([]*models.NotificationChannel, error) {
+	channels := []*models.NotificationChannel{}
+
+	err := r.db.WithContext(ctx).
+		Where("form_id = ?", formID).
+		Order("created_at, id").
+		Find(&channels).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return channels, nil
+}
+
+// Update saves a channel
+func (r *notificationChannelRepository) Update(ctx context.Context, channel *models.NotificationChannel) error {
+	return r.db.WithContext(ctx).Save(channel).Error
+}
+
+// Delete removes a channel of the form
+func (r *notificationChannelRepository) Delete(ctx context.Context, formID, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).
+		Delete(&models.NotificationChannel{}, "id = ? AND form_id = ?", id, formID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrChannelNotFound
+	}
+	return nil
+}
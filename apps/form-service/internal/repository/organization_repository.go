@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+)
+
+// OrganizationRepository stores organizations and their members
+type OrganizationRepository interface {
+	// Create creates an organization with owner as its first member
+	Create(ctx context.Context, org *models.Organization, owner *models.OrganizationMember) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Organization, error)
+	// Personal returns the personal organization of a user, creating it
+	// on first use
+	Personal(ctx context.Context, userID uuid.UUID) (*models.Organization, error)
+	// ListForUser returns the organizations a user is a member of, oldest first
+	ListForUser(ctx context.Context, userID uuid.UUID) ([]*models.Organization, error)
+
+	// GetMember returns the membership of a user, or gorm.ErrRecordNotFound
+	GetMember(ctx context.Context, orgID, userID uuid.UUID) (*models.OrganizationMember, error)
+	// AddMember adds a member, reporting false when the user already is one
+	AddMember(ctx context.Context, member *models.OrganizationMember) (bool, error)
+	// ListMembers returns the members of an organization in the order they joined
+	ListMembers(ctx context.Context, orgID uuid.UUID) ([]*models.OrganizationMember, error)
+	// ListMemberships returns the memberships of a user
+	ListMemberships(ctx context.Context, userID uuid.UUID) ([]*models.OrganizationMember, error)
+}
+
+// organizationRepository implements OrganizationRepository interface
+type organizationRepository struct {
+	db *gorm.DB
+}
+
+// NewOrganizationRepository creates a new organization repository instance
+func NewOrganizationRepository(db *gorm.DB) OrganizationRepository {
+	return &organizationRepository{db: db}
+}
+
+// Create creates an organization and its owner in one transaction
+func (r *organizationRepository) Create(ctx context.Context, org *models.Organization, owner *models.OrganizationMember) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(org).Error; err != nil {
+			return err
+		}
+		owner.OrganizationID = org.ID
+		return tx.Create(owner).Error
+	})
+}
+
+// GetByID retrieves an organization by its ID
+func (r *organizationRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Organization, error) {
+	var org models.Organization
+	if err := r.db.WithContext(ctx).First(&org, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// Personal gets the personal organization of a user. Concurrent first uses
+// create it once: the losing insert does nothing and reads the winner's.
+func (r *organizationRepository) Personal(ctx context.Context, userID uuid.UUID) (*models.Organization, error) {
+	var org models.Organization
+	err := r.db.WithContext(ctx).Where("personal AND created_by = ?", userID).Take(&org).Error
+	if err == nil {
+		return &org, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		created := models.Organization{Name: models.PersonalOrganizationName, Personal: true, CreatedBy: userID}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&created).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("personal AND created_by = ?", userID).Take(&org).Error; err != nil {
+			return err
+		}
+		owner := models.OrganizationMember{
+			OrganizationID: org.ID,
+			UserID:         userID,
+			Role:           models.OrganizationRoleOwner,
+			JoinedAt:       time.Now(),
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&owner).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// ListForUser retrieves the organizations of a user
+func (r *organizationRepository) ListForUser(ctx context.Context, userID uuid.UUID) ([]*models.Organization, error) {
+	memberships := r.db.WithContext(ctx).
+		Model(&models.OrganizationMember{}).
+		Select("organization_id").
+		Where("user_id = ?", userID)
+
+	var orgs []*models.Organization
+	err := r.db.WithContext(ctx).
+		Where("id IN (?)", memberships).
+		Order("created_at ASC").
+		Find(&orgs).Error
+	return orgs, err
+}
+
+// GetMember retrieves the membership of a user in an organization
+func (r *organizationRepository) GetMember(ctx context.Context, orgID, userID uuid.UUID) (*models.OrganizationMember, error) {
+	var member models.OrganizationMember
+	err := r.db.WithContext(ctx).
+		Where("organization_id = ? AND user_id = ?", orgID, userID).
+		Take(&member).Error
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// AddMember adds a member unless the user already is one
+func (r *organizationRepository) AddMember(ctx context.Context, member *models.OrganizationMember) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(member)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ListMembers retrieves the members of an organization
+func (r *organizationRepository) ListMembers(ctx context.Context, orgID uuid.UUID) ([]*models.OrganizationMember, error) {
+	var members []*models.OrganizationMember
+	err := r.db.WithContext(ctx).
+		Where("organization_id = ?", orgID).
+		Order("joined_at ASC, user_id ASC").
+		Find(&members).Error
+	return members, err
+}
+
+// ListMemberships retrieves the memberships of a user
+func (r *organizationRepository) ListMemberships(ctx context.Context, userID uuid.UUID) ([]*models.OrganizationMember, error) {
+	var members []*models.OrganizationMember
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("joined_at ASC, organization_id ASC").
+		Find(&members).Error
+	return members, err
+}
+
+// FormOrganizations tells the organization of forms, deleted ones included,
+// which the objects stored for a form belong to
+type FormOrganizations interface {
+	// OrganizationOf returns the organization of a form, or
+	// gorm.ErrRecordNotFound
+	OrganizationOf(ctx context.Context, formID uuid.UUID) (uuid.UUID, error)
+}
+
+// formOrganizations implements FormOrganizations interface
+type formOrganizations struct {
+	db *gorm.DB
+}
+
+// NewFormOrganizations creates a new form organization lookup
+func NewFormOrganizations(db *gorm.DB) FormOrganizations {
+	return &formOrganizations{db: db}
+}
+
+// OrganizationOf reads the organization of a form, soft-deleted or not
+func (r *formOrganizations) OrganizationOf(ctx context.Context, formID uuid.UUID) (uuid.UUID, error) {
+	var form models.Form
+	err := r.db.WithContext(ctx).Unscoped().Select("organization_id").Take(&form, "id = ?", formID).Error
+	return form.OrganizationID, err
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+)
+
+// ErrPreviewTokenNotFound is returned for preview tokens that were never
+// issued for the form
+var ErrPreviewTokenNotFound = errors.New("preview token not found")
+
+// PreviewTokenRepository defines the interface for the records of the
+// tokens sharing draft forms
+type PreviewTokenRepository interface {
+	Create(ctx context.Context, token *models.PreviewToken) error
+	Get(ctx context.Context, id uuid.UUID) (*models.PreviewToken, error)
+	// ListByForm lists the tokens of the form, most recent first
+	ListByForm(ctx context.Context, formID uuid.UUID) ([]*models.PreviewToken, error)
+	// Revoke revokes a token of the form at now. Revoking a revoked token
+	// changes nothing.
+	Revoke(ctx context.Context, formID, id uuid.UUID, now time.Time) (*models.PreviewToken, error)
+}
+
+// previewTokenRepository implements PreviewTokenRepository interface
+type previewTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewPreviewTokenRepository creates a new preview token repository instance
+func NewPreviewTokenRepository(db *gorm.DB) PreviewTokenRepository {
+	return &previewTokenRepository{db: db}
+}
+
+// Create records a new preview token
+func (r *previewTokenRepository) Create(ctx context.Context, token *models.PreviewToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+// Get retrieves a preview token
+func (r *previewTokenRepository) Get(ctx context.Context, id uuid.UUID) (*models.PreviewToken, error) {
+	var token models.PreviewToken
+
+	err := r.db.WithContext(ctx).First(&token, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPreviewTokenNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// ListByForm lists the tokens of the form
+func (r *previewTokenRepository) ListByForm(ctx context.Context, formID uuid.UUID) ([]*models.PreviewToken, error) {
+	tokens := []*models.PreviewToken{}
+
+	err := r.db.WithContext(ctx).
+		Where("form_id = ?", formID).
+		Order("created_at DESC, id").
+		Find(&tokens).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// Revoke sets the revocation time of the token unless it is already set
+func (r *previewTokenRepository) Revoke(ctx context.Context, formID, id uuid.UUID, now time.Time) (*models.PreviewToken, error) {
+	var token models.PreviewToken
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.First(&token, "id = ? AND form_id = ?", id, formID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPreviewTokenNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if token.RevokedAt != nil {
+			return nil
+		}
+
+		token.RevokedAt = &now
+		return tx.Model(&token).UpdateColumn("revoked_at", now).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+)
+
+// Erasure is what erasing a user removed: the rows affected per kind, and the
+// stored objects and cached drafts of the deleted rows, to remove once the
+// transaction committed
+type Erasure struct {
+	Counts     map[string]int64
+	ObjectKeys []string
+	Drafts     []*models.ResponseDraft
+}
+
+// UserData is everything the form service holds about a user
+type UserData struct {
+	Forms          []*models.Form
+	Questions      []*models.Question
+	Collaborations []*models.Collaborator
+	Drafts         []*models.ResponseDraft
+}
+
+// PrivacyRepository erases and exports the data of users
+type PrivacyRepository interface {
+	// EraseUser removes the collaborations, organization memberships and
+	// drafts of a user, and deletes or anonymizes the forms they own as the policy says. Erasing a user
+	// twice removes nothing the second time.
+	EraseUser(ctx context.Context, userID uuid.UUID, email string, policy models.ErasurePolicy) (*Erasure, error)
+	// ExportUser returns the forms a user owns, deleted ones included, with
+	// their questions, and the collaborations and drafts of the user
+	ExportUser(ctx context.Context, userID uuid.UUID, email string) (*UserData, error)
+}
+
+// privacyRepository implements PrivacyRepository interface
+type privacyRepository struct {
+	db *gorm.DB
+}
+
+// NewPrivacyRepository creates a new privacy repository instance
+func NewPrivacyRepository(db *gorm.DB) PrivacyRepository {
+	return &privacyRepository{db: db}
+}
+
+// EraseUser runs the erasure in one transaction, so a failure leaves the
+// user as they were for the retry
+func (r *privacyRepository) EraseUser(ctx context.Context, userID uuid.UUID, email string, policy models.ErasurePolicy) (*Erasure, error) {
+	erasure := &Erasure{Counts: make(map[string]int64)}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		owned := tx.Unscoped().Model(&models.Form{}).Select("id").Where("user_id = ?", userID)
+
+		// Drafts of the user, and of every respondent to forms deleted with
+		// them
+		drafts := tx.Where("user_id = ?", userID.String())
+		if policy != models.ErasurePolicyAnonymize {
+			drafts = drafts.Or("form_id IN (?)", owned)
+		}
+		if err := drafts.Select("form_id", "owner_key").Find(&erasure.Drafts).Error; err != nil {
+			return err
+		}
+
+		switch policy {
+		case models.ErasurePolicyAnonymize:
+			// Report recipients may be the owner's addresses
+			result := tx.Where("form_id IN (?)", owned).Delete(&models.ReportSchedule{})
+			if result.Error != nil {
+				return result.Error
+			}
+			erasure.Counts["report_schedules_removed"] = result.RowsAffected
+
+			result = tx.Unscoped().Model(&models.Form{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+				"user_id": models.ErasedOwnerID,
+				"settings": gorm.Expr(`jsonb_set(COALESCE(settings, '{}'::jsonb) #- '{notifications,owner_email}' #- '{notifications,reply_to}',
+					'{notifications,notify_owner_on_response}', 'false'::jsonb, false)`),
+			})
+			if result.Error != nil {
+				return result.Error
+			}
+			erasure.Counts["forms_anonymized"] = result.RowsAffected
+
+		default:
+			var uploads, reports []string
+			if err := tx.Model(&models.FileUpload{}).Where("form_id IN (?)", owned).Pluck("object_key", &uploads).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.Report{}).Where("form_id IN (?)", owned).Pluck("object_key", &reports).Error; err != nil {
+				return err
+			}
+			erasure.ObjectKeys = append(uploads, reports...)
+
+			steps := []struct {
+				count string
+				model interface{}
+			}{
+				{"questions_deleted", &models.Question{}},
+				{"uploads_deleted", &models.FileUpload{}},
+				{"reports_deleted", &models.Report{}},
+				{"report_schedules_removed", &models.ReportSchedule{}},
+			}
+			for _, step := range steps {
+				result := tx.Unscoped().Where("form_id IN (?)", owned).Delete(step.model)
+				if result.Error != nil {
+					return result.Error
+				}
+				erasure.Counts[step.count] = result.RowsAffected
+			}
+
+			// Collaborators of the forms go with them by cascade
+			result := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.Form{})
+			if result.Error != nil {
+				return result.Error
+			}
+			erasure.Counts["forms_deleted"] = result.RowsAffected
+		}
+
+		collaborations := tx.Unscoped().Where("user_id = ?", userID)
+		if email != "" {
+			collaborations = collaborations.Or("LOWER(email) = ?", strings.ToLower(email))
+		}
+		result := collaborations.Delete(&models.Collaborator{})
+		if result.Error != nil {
+			return result.Error
+		}
+		erasure.Counts["collaborations_removed"] = result.RowsAffected
+
+		// Anonymized forms stay in the organizations they belong to
+		result = tx.Where("user_id = ?", userID).Delete(&models.OrganizationMember{})
+		if result.Error != nil {
+			return result.Error
+		}
+		erasure.Counts["memberships_removed"] = result.RowsAffected
+
+		erasure.Counts["drafts_removed"] = int64(len(erasure.Drafts))
+		for _, draft := range erasure.Drafts {
+			err := tx.Where("form_id = ? AND owner_key = ?", draft.FormID, draft.OwnerKey).Delete(&models.ResponseDraft{}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return erasure, nil
+}
+
+// ExportUser reads the data of the user
+func (r *privacyRepository) ExportUser(ctx context.Context, userID uuid.UUID, email string) (*UserData, error) {
+	data := &UserData{}
+	db := r.db.WithContext(ctx)
+
+	if err := db.Unscoped().Where("user_id = ?", userID).Order("created_at").Find(&data.Forms).Error; err != nil {
+		return nil, err
+	}
+	if len(data.Forms) > 0 {
+		formIDs := make([]uuid.UUID, len(data.Forms))
+		for i, form := range data.Forms {
+			formIDs[i] = form.ID
+		}
+		err := db.Unscoped().Where("form_id IN ?", formIDs).Order("form_id, \"order\"").Find(&data.Questions).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	collaborations := db.Where("user_id = ?", userID)
+	if email != "" {
+		collaborations = collaborations.Or("LOWER(email) = ?", strings.ToLower(email))
+	}
+	if err := collaborations.Order("created_at").Find(&data.Collaborations).Error; err != nil {
+		return nil, err
+	}
+
+	if err := db.Where("user_id = ?", userID.String()).Order("updated_at").Find(&data.Drafts).Error; err != nil {
+		return nil, err
+	}
+	return data, nil
+}
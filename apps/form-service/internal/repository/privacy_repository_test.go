@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+)
+
+// seedUser creates a form of owner with a question, an upload and a draft,
+// and makes owner a collaborator of a form of someone else
+func seedUser(t *testing.T, db *gorm.DB, owner uuid.UUID, email string) (form, shared *models.Form) {
+	t.Helper()
+	ctx := context.Background()
+	forms := NewFormRepository(db)
+
+	form = &models.Form{UserID: owner, Title: "Feedback",
+		Settings: []byte(`{"notifications":{"notify_owner_on_response":true,"owner_email":"` + email + `"}}`)}
+	shared = &models.Form{UserID: uuid.New(), Title: "Shared"}
+	for _, f := range []*models.Form{form, shared} {
+		f.OrganizationID = personalOrganization(t, db, f.UserID)
+		if err := forms.Create(ctx, f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rows := []interface{}{
+		&models.Question{FormID: form.ID, Type: models.QuestionTypeText, Title: "Why?"},
+		&models.FileUpload{Token: uuid.NewString(), FormID: form.ID, QuestionID: uuid.New(),
+			ObjectKey: "uploads/" + form.ID.String() + "/file", ContentType: "text/plain"},
+		&models.Collaborator{FormID: shared.ID, Email: email, InvitedBy: shared.UserID},
+		&models.ResponseDraft{FormID: shared.ID, OwnerKey: "user:" + owner.String(), UserID: owner.String(),
+			Answers: []byte(`{}`), ExpiresAt: time.Now().Add(time.Hour)},
+		&models.ResponseDraft{FormID: form.ID, OwnerKey: "session:abc",
+			Answers: []byte(`{}`), ExpiresAt: time.Now().Add(time.Hour)},
+	}
+	for _, row := range rows {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return form, shared
+}
+
+// TestEraseUser checks both erasure policies, and that erasing a user again
+// removes nothing
+func TestEraseUser(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	repo := NewPrivacyRepository(db)
+
+	t.Run("delete", func(t *testing.T) {
+		owner, email := uuid.New(), "ada@example.com"
+		form, shared := seedUser(t, db, owner, email)
+
+		erasure, err := repo.EraseUser(ctx, owner, "Ada@Example.com", models.ErasurePolicyDelete)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]int64{"forms_deleted": 1, "questions_deleted": 1, "uploads_deleted": 1, "collaborations_removed": 1, "memberships_removed": 1, "drafts_removed": 2}
+		for kind, n := range want {
+			if erasure.Counts[kind] != n {
+				t.Errorf("%s = %d, want %d", kind, erasure.Counts[kind], n)
+			}
+		}
+		if len(erasure.ObjectKeys) != 1 || len(erasure.Drafts) != 2 {
+			t.Errorf("objects = %v, drafts = %d; want the upload and both drafts", erasure.ObjectKeys, len(erasure.Drafts))
+		}
+
+		var remaining int64
+		db.Unscoped().Model(&models.Form{}).Where("id = ?", form.ID).Count(&remaining)
+		if remaining != 0 {
+			t.Error("the owned form was not deleted")
+		}
+		db.Model(&models.Form{}).Where("id = ?", shared.ID).Count(&remaining)
+		if remaining != 1 {
+			t.Error("the form of another owner was deleted")
+		}
+
+		again, err := repo.EraseUser(ctx, owner, email, models.ErasurePolicyDelete)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for kind, n := range again.Counts {
+			if n != 0 {
+				t.Errorf("second erasure removed %d %s", n, kind)
+			}
+		}
+	})
+
+	t.Run("anonymize", func(t *testing.T) {
+		owner, email := uuid.New(), "grace@example.com"
+		form, _ := seedUser(t, db, owner, email)
+
+		erasure, err := repo.EraseUser(ctx, owner, email, models.ErasurePolicyAnonymize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if erasure.Counts["forms_anonymized"] != 1 || erasure.Counts["drafts_removed"] != 1 || len(erasure.ObjectKeys) != 0 {
+			t.Errorf("counts = %v, objects = %v; want the form anonymized and only the user's draft removed", erasure.Counts, erasure.ObjectKeys)
+		}
+
+		var kept models.Form
+		if err := db.First(&kept, "id = ?", form.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		settings, err := kept.GetSettings()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kept.UserID != models.ErasedOwnerID || settings.Notifications.OwnerEmail != "" || settings.Notifications.NotifyOwnerOnResponse {
+			t.Errorf("anonymized form = owner %s, notifications %+v", kept.UserID, settings.Notifications)
+		}
+	})
+}
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+)
+
+// ErrScheduleNotFound is returned when a form is not enrolled in reports
+var ErrScheduleNotFound = errors.New("report schedule not found")
+
+// ReportRepository stores report schedules and the reports generated from them
+type ReportRepository interface {
+	UpsertSchedule(ctx context.Context, schedule *models.ReportSchedule) error
+	GetSchedule(ctx context.Context, formID uuid.UUID) (*models.ReportSchedule, error)
+	DeleteSchedule(ctx context.Context, formID uuid.UUID) error
+	// DueSchedules returns up to limit schedules whose next run is at or before now
+	DueSchedules(ctx context.Context, now time.Time, limit int) ([]*models.ReportSchedule, error)
+	// AdvanceSchedule moves the next run of a schedule from from to next,
+	// reporting false when another run already moved it
+	AdvanceSchedule(ctx context.Context, formID uuid.UUID, from, next time.Time) (bool, error)
+	// CreateReport records a report, reporting false when the form already
+	// has a report of the same period end
+	CreateReport(ctx context.Context, report *models.Report) (bool, error)
+	// ListReports returns the reports of a form, newest first
+	ListReports(ctx context.Context, formID uuid.UUID, limit int) ([]*models.Report, error)
+}
+
+// reportRepository implements ReportRepository interface
+type reportRepository struct {
+	db *gorm.DB
+}
+
+// NewReportRepository creates a new report repository instance
+func NewReportRepository(db *gorm.DB) ReportRepository {
+	return &reportRepository{db: db}
+}
+
+// UpsertSchedule creates or replaces the schedule of a form
+func (r *reportRepository) UpsertSchedule(ctx context.Context, schedule *models.ReportSchedule) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "form_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"frequency", "weekday", "send_at", "timezone", "recipients", "format", "include_empty", "next_run_at", "updated_at",
+		}),
+	}).Create(schedule).Error
+}
+
+// GetSchedule retrieves the schedule of a form
+func (r *reportRepository) GetSchedule(ctx context.Context, formID uuid.UUID) (*models.ReportSchedule, error) {
+	var schedule models.ReportSchedule
+
+	err := r.db.WithContext(ctx).Where("form_id = ?", formID).First(&schedule).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrScheduleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &schedule, nil
+}
+
+// DeleteSchedule removes the schedule of a form. Reports already generated
+// are kept.
+func (r *reportRepository) DeleteSchedule(ctx context.Context, formID uuid.UUID) error {
+	result := r.db.WithContext(ctx).Where("form_id = ?", formID).Delete(&models.ReportSchedule{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrScheduleNotFound
+	}
+	return nil
+}
+
+// DueSchedules returns the most overdue schedules first
+func (r *reportRepository) DueSchedules(ctx context.Context, now time.Time, limit int) ([]*models.ReportSchedule, error) {
+	var schedules []*models.ReportSchedule
+
+	err := r.db.WithContext(ctx).
+		Where("next_run_at <= ?", now).
+		Order("next_run_at ASC").
+		Limit(limit).
+		Find(&schedules).Error
+
+	return schedules, err
+}
+
+// AdvanceSchedule updates next_run_at only while it still holds from
+func (r *reportRepository) AdvanceSchedule(ctx context.Context, formID uuid.UUID, from, next time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.ReportSchedule{}).
+		Where("form_id = ? AND next_run_at = ?", formID, from).
+		Update("next_run_at", next)
+	return result.RowsAffected > 0, result.Error
+}
+
+// CreateReport inserts the report with ON CONFLICT DO NOTHING on the form
+// and period end
+func (r *reportRepository) CreateReport(ctx context.Context, report *models.Report) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "form_id"}, {Name: "period_end"}},
+		DoNothing: true,
+	}).Create(report)
+	return result.RowsAffected > 0, result.Error
+}
+
+// ListReports returns the latest reports of a form
+func (r *reportRepository) ListReports(ctx context.Context, formID uuid.UUID, limit int) ([]*models.Report, error) {
+	var reports []*models.Report
+
+	err := r.db.WithContext(ctx).
+		Where("form_id = ?", formID).
+		Order("period_end DESC").
+		Limit(limit).
+		Find(&reports).Error
+
+	return reports, err
+}
+
+// Lock is a lease held by one replica at a time
+type Lock interface {
+	// Acquire takes or renews the lease for ttl, reporting whether this
+	// holder has it
+	Acquire(ctx context.Context, ttl time.Duration) (bool, error)
+	// Release gives the lease up if this holder has it
+	Release(ctx context.Context) error
+}
+
+// redisLock is a lease on a Redis key holding a random token of its holder
+type redisLock struct {
+	client *redis.Client
+	key    string
+	token  string
+}
+
+// NewRedisLock creates a lock on key, identified by a random holder token
+func NewRedisLock(client *redis.Client, key string) Lock {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return &redisLock{client: client, key: key, token: hex.EncodeToString(b)}
+}
+
+// renewScript extends the lease when it is still held by the caller
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// releaseScript deletes the lease when it is still held by the caller
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// Acquire takes the lease with SET NX, or extends it when already held
+func (l *redisLock) Acquire(ctx context.Context, ttl time.Duration) (bool, error) {
+	acquired, err := l.client.SetNX(ctx, l.key, l.token, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lock %s: %w", l.key, err)
+	}
+	if acquired {
+		return true, nil
+	}
+
+	renewed, err := renewScript.Run(ctx, l.client, []string{l.key}, l.token, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to renew lock %s: %w", l.key, err)
+	}
+	return renewed == 1, nil
+}
+
+// Release deletes the lease if it is still held
+func (l *redisLock) Release(ctx context.Context) error {
+	return releaseScript.Run(ctx, l.client, []string{l.key}, l.token).Err()
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+)
+
+var (
+	// ErrResponseDeletionNotFound is returned for bulk deletion jobs that
+	// don't exist
+	ErrResponseDeletionNotFound = errors.New("response deletion not found")
+	// ErrConfirmationUsed is returned when a confirmation token started a
+	// deletion already
+	ErrConfirmationUsed = errors.New("confirmation token already used")
+)
+
+// ResponseDeletionRepository stores the bulk deletion jobs of the responses
+// of forms. Replicas claim queued jobs, so each runs on a single replica at
+// a time.
+type ResponseDeletionRepository interface {
+	// Create records a new job, failing with ErrConfirmationUsed when its
+	// confirmation started another one
+	Create(ctx context.Context, job *models.ResponseDeletionJob) error
+	Get(ctx context.Context, id uuid.UUID) (*models.ResponseDeletionJob, error)
+	// Claim marks the oldest queued job running and returns it, nil when
+	// there is none. A running job whose progress was last saved before
+	// staleBefore was abandoned by its replica and is claimed again.
+	Claim(ctx context.Context, now, staleBefore time.Time) (*models.ResponseDeletionJob, error)
+	// SaveProgress saves the count and the pending batch of a running job
+	SaveProgress(ctx context.Context, job *models.ResponseDeletionJob) error
+	// Finish records the final status of a running job
+	Finish(ctx context.Context, job *models.ResponseDeletionJob) error
+	// Resume queues a failed job again, reporting false when it had not
+	// failed
+	Resume(ctx context.Context, id uuid.UUID, now time.Time) (bool, error)
+}
+
+// responseDeletionRepository implements ResponseDeletionRepository interface
+type responseDeletionRepository struct {
+	db *gorm.DB
+}
+
+// NewResponseDeletionRepository creates a new response deletion repository
+// instance
+func NewResponseDeletionRepository(db *gorm.DB) ResponseDeletionRepository {
+	return &responseDeletionRepository{db: db}
+}
+
+// Create records a job
+func (r *responseDeletionRepository) Create(ctx context.Context, job *models.ResponseDeletionJob) error {
+	err := r.db.WithContext(ctx).Create(job).Error
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_response_deletion_jobs_confirmation_id" {
+		return ErrConfirmationUsed
+	}
+	return err
+}
+
+// Get retrieves a job by its ID
+func (r *responseDeletionRepository) Get(ctx context.Context, id uuid.UUID) (*models.ResponseDeletionJob, error) {
+	var job models.ResponseDeletionJob
+
+	err := r.db.WithContext(ctx).First(&job, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrResponseDeletionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// Claim marks the oldest claimable job running. Jobs locked by another
+// replica claiming them are skipped.
+func (r *responseDeletionRepository) Claim(ctx context.Context, now, staleBefore time.Time) (*models.ResponseDeletionJob, error) {
+	var jobs []*models.ResponseDeletionJob
+
+	err := r.db.WithContext(ctx).Raw(`UPDATE response_deletion_jobs
+		SET status = ?, started_at = COALESCE(started_at, ?), updated_at = ?
+		WHERE id = (
+			SELECT id FROM response_deletion_jobs
+			WHERE status = ? OR (status = ? AND updated_at < ?)
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		models.ResponseDeletionRunning, now, now,
+		models.ResponseDeletionQueued, models.ResponseDeletionRunning, staleBefore,
+	).Scan(&jobs).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+	return jobs[0], nil
+}
+
+// SaveProgress saves the count and the pending batch of a job
+func (r *responseDeletionRepository) SaveProgress(ctx context.Context, job *models.ResponseDeletionJob) error {
+	return r.db.WithContext(ctx).
+		Model(&models.ResponseDeletionJob{}).
+		Where("id = ? AND status = ?", job.ID, models.ResponseDeletionRunning).
+		Updates(map[string]interface{}{
+			"deleted":    job.Deleted,
+			"pending":    job.Pending,
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// Finish records the final status, count, pending batch and error of a
+// running job
+func (r *responseDeletionRepository) Finish(ctx context.Context, job *models.ResponseDeletionJob) error {
+	return r.db.WithContext(ctx).
+		Model(&models.ResponseDeletionJob{}).
+		Where("id = ? AND status = ?", job.ID, models.ResponseDeletionRunning).
+		Updates(map[string]interface{}{
+			"status":      job.Status,
+			"deleted":     job.Deleted,
+			"pending":     job.Pending,
+			"error":       job.Error,
+			"finished_at": job.FinishedAt,
+			"updated_at":  time.Now(),
+		}).Error
+}
+
+// Resume queues a failed job, clearing its error
+func (r *responseDeletionRepository) Resume(ctx context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.ResponseDeletionJob{}).
+		Where("id = ? AND status = ?", id, models.ResponseDeletionFailed).
+		Updates(map[string]interface{}{
+			"status":      models.ResponseDeletionQueued,
+			"error":       "",
+			"finished_at": nil,
+			"updated_at":  now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+)
+
+var (
+	// ErrTransferPending is returned when the form already has a pending
+	// transfer
+	ErrTransferPending = errors.New("form already has a pending transfer")
+	// ErrTransferNotPending is returned when a transfer was accepted,
+	// cancelled or expired meanwhile
+	ErrTransferNotPending = errors.New("transfer is no longer pending")
+	// ErrFormMoved is returned when the form of a transfer changed owner or
+	// organization, or was deleted, since the transfer was requested
+	ErrFormMoved = errors.New("form changed owner since the transfer was requested")
+)
+
+// TransferDestination is where an accepted transfer moves its form
+type TransferDestination struct {
+	OwnerID        uuid.UUID
+	OrganizationID uuid.UUID
+	// KeepCollaboratorsOutside keeps the collaborators who are not members
+	// of a new organization, as personal organizations have no other members
+	KeepCollaboratorsOutside bool
+}
+
+// TransferredForm is a form moved by a transfer, with the slug it had
+type TransferredForm struct {
+	Form         *models.Form
+	PreviousSlug string
+	// CollaboratorsRemoved counts the collaborators the move removed
+	CollaboratorsRemoved int64
+}
+
+// TransferRepository stores form transfers and moves the forms accepted
+type TransferRepository interface {
+	// Create records a pending transfer, or returns ErrTransferPending
+	Create(ctx context.Context, transfer *models.FormTransfer) error
+	// GetByID returns a transfer, or gorm.ErrRecordNotFound
+	GetByID(ctx context.Context, id uuid.UUID) (*models.FormTransfer, error)
+	// ListFor returns the transfers from or to the user, or from or to the
+	// organizations, newest first. An empty status lists every status.
+	ListFor(ctx context.Context, userID uuid.UUID, orgIDs []uuid.UUID, status models.TransferStatus) ([]*models.FormTransfer, error)
+	// Cancel marks a pending transfer cancelled by a user, or returns
+	// ErrTransferNotPending
+	Cancel(ctx context.Context, transfer *models.FormTransfer, by uuid.UUID, at time.Time) error
+	// Accept marks a pending transfer accepted and moves its form to the
+	// destination in one transaction. It returns ErrTransferNotPending when
+	// the transfer was resolved or expired meanwhile, and ErrFormMoved.
+	Accept(ctx context.Context, transfer *models.FormTransfer, to TransferDestination, by uuid.UUID, at time.Time) (*TransferredForm, error)
+	// ExpirePending marks the pending transfers expired at now
+	ExpirePending(ctx context.Context, now time.Time) (int64, error)
+}
+
+// transferRepository implements TransferRepository interface
+type transferRepository struct {
+	db *gorm.DB
+}
+
+// NewTransferRepository creates a new transfer repository instance
+func NewTransferRepository(db *gorm.DB) TransferRepository {
+	return &transferRepository{db: db}
+}
+
+// Create records a transfer
+func (r *transferRepository) Create(ctx context.Context, transfer *models.FormTransfer) error {
+	err := r.db.WithContext(ctx).Create(transfer).Error
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_form_transfers_pending_form" {
+		return ErrTransferPending
+	}
+	return err
+}
+
+// GetByID retrieves a transfer by its ID
+func (r *transferRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.FormTransfer, error) {
+	var transfer models.FormTransfer
+	if err := r.db.WithContext(ctx).First(&transfer, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// ListFor retrieves the transfers of both parties
+func (r *transferRepository) ListFor(ctx context.Context, userID uuid.UUID, orgIDs []uuid.UUID, status models.TransferStatus) ([]*models.FormTransfer, error) {
+	parties := r.db.Where("from_user_id = ? OR to_user_id = ? OR requested_by = ?", userID, userID, userID)
+	if len(orgIDs) > 0 {
+		parties = parties.Or("from_organization_id IN ? OR to_organization_id IN ?", orgIDs, orgIDs)
+	}
+
+	query := r.db.WithContext(ctx).Where(parties)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var transfers []*models.FormTransfer
+	err := query.Order("created_at DESC, id DESC").Find(&transfers).Error
+	return transfers, err
+}
+
+// Cancel resolves a pending transfer as cancelled. Of an accept and a cancel
+// racing, the first to update the row wins; the other finds it resolved.
+func (r *transferRepository) Cancel(ctx context.Context, transfer *models.FormTransfer, by uuid.UUID, at time.Time) error {
+	result := r.db.WithContext(ctx).Model(&models.FormTransfer{}).
+		Where("id = ? AND status = ?", transfer.ID, models.TransferStatusPending).
+		Updates(map[string]interface{}{
+			"status":      models.TransferStatusCancelled,
+			"resolved_by": by,
+			"resolved_at": at,
+			"updated_at":  at,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTransferNotPending
+	}
+	transfer.Status, transfer.ResolvedBy, transfer.ResolvedAt, transfer.UpdatedAt = models.TransferStatusCancelled, &by, &at, at
+	return nil
+}
+
+// Accept resolves the transfer first, which locks it until the form is
+// moved: a cancel racing the accept waits, then finds it resolved.
+func (r *transferRepository) Accept(ctx context.Context, transfer *models.FormTransfer, to TransferDestination, by uuid.UUID, at time.Time) (*TransferredForm, error) {
+	var moved TransferredForm
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		claim := tx.Model(&models.FormTransfer{}).
+			Where("id = ? AND status = ? AND expires_at > ?", transfer.ID, models.TransferStatusPending, at).
+			Updates(map[string]interface{}{
+				"status":      models.TransferStatusAccepted,
+				"resolved_by": by,
+				"resolved_at": at,
+				"updated_at":  at,
+			})
+		if claim.Error != nil {
+			return claim.Error
+		}
+		if claim.RowsAffected == 0 {
+			return ErrTransferNotPending
+		}
+
+		var form models.Form
+		err := tx.Raw(`SELECT * FROM "forms" WHERE "id" = ? AND "user_id" = ? AND "organization_id" = ?
+			AND "deleted_at" IS NULL FOR UPDATE`, transfer.FormID, transfer.FromUserID, transfer.FromOrganizationID).
+			Scan(&form).Error
+		if err != nil {
+			return err
+		}
+		if form.ID == uuid.Nil {
+			return ErrFormMoved
+		}
+		moved.PreviousSlug = slugValue(form.Slug)
+
+		orgChanged := to.OrganizationID != form.OrganizationID
+		if orgChanged {
+			// The slug moves to the namespace of the new organization unless
+			// a form there has it; links to the previous one stop working
+			if form.Slug != nil {
+				var taken int64
+				err := tx.Model(&models.Form{}).
+					Where("organization_id = ? AND slug = ? AND id <> ?", to.OrganizationID, *form.Slug, form.ID).
+					Count(&taken).Error
+				if err != nil {
+					return err
+				}
+				if taken > 0 {
+					form.Slug = nil
+				} else if err := tx.Where("organization_id = ? AND slug = ?", to.OrganizationID, *form.Slug).
+					Delete(&models.FormSlugRedirect{}).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Where("form_id = ?", form.ID).Delete(&models.FormSlugRedirect{}).Error; err != nil {
+				return err
+			}
+
+			// Exports of the responses are scheduled with the organization
+			err := tx.Model(&models.ExportJob{}).Where("form_id = ?", form.ID).
+				UpdateColumn("organization_id", to.OrganizationID).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		err = tx.Model(&models.Form{}).Where("id = ?", form.ID).UpdateColumns(map[string]interface{}{
+			"user_id":         to.OwnerID,
+			"organization_id": to.OrganizationID,
+			"slug":            form.Slug,
+			"updated_at":      at,
+		}).Error
+		if err != nil {
+			return slugError(err)
+		}
+		form.UserID, form.OrganizationID, form.UpdatedAt = to.OwnerID, to.OrganizationID, at
+
+		// The owner is no collaborator, and neither are users outside the
+		// new organization
+		collaborators := tx.Where("form_id = ? AND user_id = ?", form.ID, to.OwnerID)
+		if orgChanged && !to.KeepCollaboratorsOutside {
+			members := tx.Model(&models.OrganizationMember{}).Select("user_id").Where("organization_id = ?", to.OrganizationID)
+			collaborators = tx.Where("form_id = ? AND (user_id = ? OR user_id IS NULL OR user_id NOT IN (?))", form.ID, to.OwnerID, members)
+		}
+		removed := collaborators.Delete(&models.Collaborator{})
+		if removed.Error != nil {
+			return removed.Error
+		}
+		moved.CollaboratorsRemoved = removed.RowsAffected
+		moved.Form = &form
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	transfer.Status, transfer.ResolvedBy, transfer.ResolvedAt, transfer.UpdatedAt = models.TransferStatusAccepted, &by, &at, at
+	return &moved, nil
+}
+
+// ExpirePending expires the pending transfers not accepted in time
+func (r *transferRepository) ExpirePending(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&models.FormTransfer{}).
+		Where("status = ? AND expires_at <= ?", models.TransferStatusPending, now).
+		Updates(map[string]interface{}{
+			"status":      models.TransferStatusExpired,
+			"resolved_at": gorm.Expr("expires_at"),
+			"updated_at":  now,
+		})
+	return result.RowsAffected, result.Error
+}
+
+// slugValue returns a slug, empty for none
+func slugValue(slug *string) string {
+	if slug == nil {
+		return ""
+	}
+	return *slug
+}
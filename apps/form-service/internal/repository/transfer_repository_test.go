@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+)
+
+// TestTransferAccept checks that an accepted transfer moves the form with
+// its collaborators in the new organization, and that of an accept and a
+// cancel racing exactly one wins
+func TestTransferAccept(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	forms, orgs, transfers := NewFormRepository(db), NewOrganizationRepository(db), NewTransferRepository(db)
+	owner, admin, member, outsider := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	acme := &models.Organization{Name: "Acme"}
+	if err := orgs.Create(ctx, acme, &models.OrganizationMember{UserID: admin, Role: models.OrganizationRoleOwner}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := orgs.AddMember(ctx, &models.OrganizationMember{OrganizationID: acme.ID, UserID: member, Role: models.OrganizationRoleMember}); err != nil {
+		t.Fatal(err)
+	}
+
+	request := func() (*models.Form, *models.FormTransfer) {
+		t.Helper()
+		form := &models.Form{UserID: owner, OrganizationID: personalOrganization(t, db, owner), Title: "Feedback"}
+		if err := forms.Create(ctx, form); err != nil {
+			t.Fatal(err)
+		}
+		for _, userID := range []uuid.UUID{admin, member, outsider} {
+			userID := userID
+			collaborator := &models.Collaborator{FormID: form.ID, UserID: &userID, Email: "c@example.com", InvitedBy: owner}
+			if err := db.Create(collaborator).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+		transfer := &models.FormTransfer{FormID: form.ID, FormTitle: form.Title, FromUserID: owner, FromOrganizationID: form.OrganizationID,
+			ToOrganizationID: &acme.ID, Status: models.TransferStatusPending, RequestedBy: owner, ExpiresAt: time.Now().Add(models.TransferTTL)}
+		if err := transfers.Create(ctx, transfer); err != nil {
+			t.Fatal(err)
+		}
+		return form, transfer
+	}
+
+	form, transfer := request()
+	second := *transfer
+	second.ID = uuid.Nil
+	if err := transfers.Create(ctx, &second); !errors.Is(err, ErrTransferPending) {
+		t.Fatalf("second pending transfer: err = %v, want ErrTransferPending", err)
+	}
+
+	moved, err := transfers.Accept(ctx, transfer, TransferDestination{OwnerID: admin, OrganizationID: acme.ID}, admin, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved.Form.UserID != admin || moved.Form.OrganizationID != acme.ID || moved.CollaboratorsRemoved != 2 {
+		t.Errorf("moved form %+v, %d collaborators removed; want it owned by the admin in Acme and 2 removed", moved.Form, moved.CollaboratorsRemoved)
+	}
+	var remaining []models.Collaborator
+	if err := db.Where("form_id = ?", form.ID).Find(&remaining).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != 1 || *remaining[0].UserID != member {
+		t.Errorf("remaining collaborators = %+v, want the member of Acme", remaining)
+	}
+	if err := transfers.Cancel(ctx, transfer, owner, time.Now()); !errors.Is(err, ErrTransferNotPending) {
+		t.Errorf("cancel once accepted: err = %v, want ErrTransferNotPending", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		form, transfer := request()
+		accepting, cancelling := *transfer, *transfer
+		var wg sync.WaitGroup
+		var acceptErr, cancelErr error
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, acceptErr = transfers.Accept(ctx, &accepting, TransferDestination{OwnerID: admin, OrganizationID: acme.ID}, admin, time.Now())
+		}()
+		go func() {
+			defer wg.Done()
+			cancelErr = transfers.Cancel(ctx, &cancelling, owner, time.Now())
+		}()
+		wg.Wait()
+
+		if (acceptErr == nil) == (cancelErr == nil) {
+			t.Fatalf("accept: %v, cancel: %v; want exactly one to win", acceptErr, cancelErr)
+		}
+		current, err := forms.GetByID(ctx, form.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (acceptErr == nil) != (current.OrganizationID == acme.ID) {
+			t.Errorf("accept: %v, but the form is in %s", acceptErr, current.OrganizationID)
+		}
+	}
+
+	// Expired transfers can't be accepted
+	_, expiring := request()
+	if _, err := transfers.Accept(ctx, expiring, TransferDestination{OwnerID: admin, OrganizationID: acme.ID}, admin, expiring.ExpiresAt); !errors.Is(err, ErrTransferNotPending) {
+		t.Errorf("accept at the expiry: err = %v, want ErrTransferNotPending", err)
+	}
+	if expired, err := transfers.ExpirePending(ctx, expiring.ExpiresAt); err != nil || expired != 1 {
+		t.Errorf("ExpirePending = %d, %v; want 1", expired, err)
+	}
+}
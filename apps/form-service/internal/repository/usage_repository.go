@@ -0,0 +1,304 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+)
+
+// UsageKey names the usage of an organization in a month
+type UsageKey struct {
+	OrganizationID uuid.UUID
+	Month          string
+}
+
+// UsageCounter counts usage events as they happen, ahead of the usage
+// table. Each event ID is counted once, so events replayed or retried are
+// not counted twice.
+type UsageCounter interface {
+	// Add counts the event in the month it happened, reporting false when
+	// its ID was counted already
+	Add(ctx context.Context, event *models.UsageEvent) (bool, error)
+	// Get returns the counts of an organization in a month, zero when none
+	Get(ctx context.Context, key UsageKey) (*models.OrganizationUsage, error)
+	// Set replaces the counts of an organization in a month
+	Set(ctx context.Context, usage *models.OrganizationUsage) error
+	// TakeDirty removes and returns up to n organization months counted
+	// since they were last taken
+	TakeDirty(ctx context.Context, n int) ([]UsageKey, error)
+	// MarkDirty returns organization months to be taken again
+	MarkDirty(ctx context.Context, keys []UsageKey) error
+}
+
+// UsageRepository stores the usage of organizations per month and counts it
+// from the tables of record for reconciliation
+type UsageRepository interface {
+	// Get returns the usage of an organization in a month, zero when none
+	Get(ctx context.Context, key UsageKey) (*models.OrganizationUsage, error)
+	// Merge saves counts flushed from the counter, never lowering a count
+	// already stored, as a counter restarted from zero would
+	Merge(ctx context.Context, usage *models.OrganizationUsage) error
+	// Reconcile replaces the usage with counts from the tables of record
+	Reconcile(ctx context.Context, usage *models.OrganizationUsage) error
+	// Subtract lowers a count stored for the usage of an organization in a
+	// month by quantity, not below zero, for usage released
+	Subtract(ctx context.Context, key UsageKey, metric models.UsageMetric, quantity int64) error
+	// Organizations lists the organizations with usage stored for the
+	// month, or forms, files or exports created in [start, end)
+	Organizations(ctx context.Context, month string, start, end time.Time) ([]uuid.UUID, error)
+	// Actual counts the forms created, the bytes of the files attached and
+	// the exports of an organization in [start, end) from the tables of
+	// record. Responses are left zero; the projection counts them.
+	Actual(ctx context.Context, orgID uuid.UUID, start, end time.Time) (*models.OrganizationUsage, error)
+	// FormIDs lists the forms of an organization, deleted ones included
+	FormIDs(ctx context.Context, orgID uuid.UUID) ([]string, error)
+	// CountActiveForms counts the forms of an organization not closed
+	CountActiveForms(ctx context.Context, orgID uuid.UUID) (int64, error)
+}
+
+const (
+	// usageCounterTTL keeps the counters of a month and the IDs of its
+	// events past the end of the month, for the last flushes and replays
+	usageCounterTTL = 62 * 24 * time.Hour
+	// usageDirtyKey is the set of organization months to flush
+	usageDirtyKey = "usage:dirty"
+)
+
+// redisUsageCounter keeps a hash of counts per organization month and a
+// key per event ID counted
+type redisUsageCounter struct {
+	client *redis.Client
+}
+
+// NewRedisUsageCounter creates a usage counter backed by Redis
+func NewRedisUsageCounter(client *redis.Client) UsageCounter {
+	return &redisUsageCounter{client: client}
+}
+
+func usageCounterKey(key UsageKey) string {
+	return fmt.Sprintf("usage:%s:%s", key.OrganizationID, key.Month)
+}
+
+func usageEventKey(event *models.UsageEvent) string {
+	return fmt.Sprintf("usage:event:%s:%s", event.Metric, event.ID)
+}
+
+// addUsageScript counts an event unless its ID was counted, and queues its
+// organization month for flushing
+var addUsageScript = redis.NewScript(`
+if not redis.call("SET", KEYS[2], "1", "NX", "EX", ARGV[3]) then
+	return 0
+end
+redis.call("HINCRBY", KEYS[1], ARGV[1], ARGV[2])
+redis.call("EXPIRE", KEYS[1], ARGV[3])
+redis.call("SADD", KEYS[3], ARGV[4])
+return 1
+`)
+
+// Add counts the event atomically with the record of its ID
+func (c *redisUsageCounter) Add(ctx context.Context, event *models.UsageEvent) (bool, error) {
+	key := UsageKey{OrganizationID: event.OrganizationID, Month: models.UsageMonth(event.At)}
+	added, err := addUsageScript.Run(ctx, c.client,
+		[]string{usageCounterKey(key), usageEventKey(event), usageDirtyKey},
+		string(event.Metric), event.Quantity, int(usageCounterTTL.Seconds()), dirtyMember(key),
+	).Int()
+	if err != nil {
+		return false, err
+	}
+	return added == 1, nil
+}
+
+// Get reads the counts of the hash
+func (c *redisUsageCounter) Get(ctx context.Context, key UsageKey) (*models.OrganizationUsage, error) {
+	values, err := c.client.HGetAll(ctx, usageCounterKey(key)).Result()
+	if err != nil {
+		return nil, err
+	}
+	usage := &models.OrganizationUsage{OrganizationID: key.OrganizationID, Month: key.Month}
+	for _, metric := range models.UsageMetrics {
+		if value, ok := values[string(metric)]; ok {
+			quantity, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s count %q: %w", metric, value, err)
+			}
+			usage.Set(metric, quantity)
+		}
+	}
+	return usage, nil
+}
+
+// Set replaces the counts of the hash
+func (c *redisUsageCounter) Set(ctx context.Context, usage *models.OrganizationUsage) error {
+	key := usageCounterKey(UsageKey{OrganizationID: usage.OrganizationID, Month: usage.Month})
+	values := make(map[string]interface{}, len(models.UsageMetrics))
+	for _, metric := range models.UsageMetrics {
+		values[string(metric)] = usage.Get(metric)
+	}
+	pipe := c.client.TxPipeline()
+	pipe.HSet(ctx, key, values)
+	pipe.Expire(ctx, key, usageCounterTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// TakeDirty pops members of the dirty set
+func (c *redisUsageCounter) TakeDirty(ctx context.Context, n int) ([]UsageKey, error) {
+	members, err := c.client.SPopN(ctx, usageDirtyKey, int64(n)).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+	keys := make([]UsageKey, 0, len(members))
+	for _, member := range members {
+		orgID, month, ok := strings.Cut(member, ":")
+		id, err := uuid.Parse(orgID)
+		if !ok || err != nil {
+			continue
+		}
+		keys = append(keys, UsageKey{OrganizationID: id, Month: month})
+	}
+	return keys, nil
+}
+
+// MarkDirty adds the keys back to the dirty set
+func (c *redisUsageCounter) MarkDirty(ctx context.Context, keys []UsageKey) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	members := make([]interface{}, len(keys))
+	for i, key := range keys {
+		members[i] = dirtyMember(key)
+	}
+	return c.client.SAdd(ctx, usageDirtyKey, members...).Err()
+}
+
+func dirtyMember(key UsageKey) string {
+	return key.OrganizationID.String() + ":" + key.Month
+}
+
+// usageRepository implements UsageRepository interface
+type usageRepository struct {
+	db *gorm.DB
+}
+
+// NewUsageRepository creates a new usage repository instance
+func NewUsageRepository(db *gorm.DB) UsageRepository {
+	return &usageRepository{db: db}
+}
+
+// Get retrieves the usage row of the organization month
+func (r *usageRepository) Get(ctx context.Context, key UsageKey) (*models.OrganizationUsage, error) {
+	var usage models.OrganizationUsage
+	err := r.db.WithContext(ctx).
+		First(&usage, "organization_id = ? AND month = ?", key.OrganizationID, key.Month).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.OrganizationUsage{OrganizationID: key.OrganizationID, Month: key.Month}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
+
+// Merge upserts the usage, keeping the greater of each count
+func (r *usageRepository) Merge(ctx context.Context, usage *models.OrganizationUsage) error {
+	assignments := map[string]interface{}{"updated_at": gorm.Expr("EXCLUDED.updated_at")}
+	for _, metric := range models.UsageMetrics {
+		column := string(metric)
+		assignments[column] = gorm.Expr(fmt.Sprintf(`GREATEST("organization_usage"."%s", EXCLUDED."%s")`, column, column))
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "organization_id"}, {Name: "month"}},
+		DoUpdates: clause.Assignments(assignments),
+	}).Create(usage).Error
+}
+
+// Reconcile upserts the usage, replacing every count
+func (r *usageRepository) Reconcile(ctx context.Context, usage *models.OrganizationUsage) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "organization_id"}, {Name: "month"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"forms_created", "responses", "storage_bytes", "exports", "updated_at", "reconciled_at",
+		}),
+	}).Create(usage).Error
+}
+
+// Subtract lowers the count of the metric in the usage row, if there is one
+func (r *usageRepository) Subtract(ctx context.Context, key UsageKey, metric models.UsageMetric, quantity int64) error {
+	column := string(metric)
+	return r.db.WithContext(ctx).
+		Model(&models.OrganizationUsage{}).
+		Where("organization_id = ? AND month = ?", key.OrganizationID, key.Month).
+		Updates(map[string]interface{}{
+			column:       gorm.Expr(fmt.Sprintf(`GREATEST("%s" - ?, 0)`, column), quantity),
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// Organizations unions the organizations of the usage rows and of the forms,
+// attached files and exports created in the period
+func (r *usageRepository) Organizations(ctx context.Context, month string, start, end time.Time) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT organization_id FROM organization_usage WHERE month = ?
+		UNION
+		SELECT organization_id FROM forms WHERE created_at >= ? AND created_at < ?
+		UNION
+		SELECT f.organization_id FROM file_uploads u JOIN forms f ON f.id = u.form_id
+		WHERE u.status = ? AND u.attached_at >= ? AND u.attached_at < ?
+		UNION
+		SELECT f.organization_id FROM form_export_jobs j JOIN forms f ON f.id = j.form_id
+		WHERE j.created_at >= ? AND j.created_at < ?`,
+		month, start, end, models.FileUploadStatusAttached, start, end, start, end).
+		Scan(&ids).Error
+	return ids, err
+}
+
+// Actual counts the usage from forms, file_uploads and form_export_jobs.
+// Soft deleted forms count: they were created in the period all the same.
+func (r *usageRepository) Actual(ctx context.Context, orgID uuid.UUID, start, end time.Time) (*models.OrganizationUsage, error) {
+	usage := &models.OrganizationUsage{OrganizationID: orgID, Month: models.UsageMonth(start)}
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT
+			(SELECT COUNT(*) FROM forms
+			 WHERE organization_id = ? AND created_at >= ? AND created_at < ?) AS forms_created,
+			(SELECT COALESCE(SUM(u.size_bytes), 0) FROM file_uploads u JOIN forms f ON f.id = u.form_id
+			 WHERE f.organization_id = ? AND u.status = ? AND u.attached_at >= ? AND u.attached_at < ?) AS storage_bytes,
+			(SELECT COUNT(*) FROM form_export_jobs j JOIN forms f ON f.id = j.form_id
+			 WHERE f.organization_id = ? AND j.created_at >= ? AND j.created_at < ?) AS exports`,
+		orgID, start, end,
+		orgID, models.FileUploadStatusAttached, start, end,
+		orgID, start, end).
+		Row().Scan(&usage.FormsCreated, &usage.StorageBytes, &usage.Exports)
+	if err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// FormIDs lists the IDs of the forms of the organization
+func (r *usageRepository) FormIDs(ctx context.Context, orgID uuid.UUID) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).Unscoped().Model(&models.Form{}).
+		Where("organization_id = ?", orgID).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// CountActiveForms counts the drafts and published forms of the organization
+func (r *usageRepository) CountActiveForms(ctx context.Context, orgID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Form{}).
+		Where("organization_id = ? AND status <> ?", orgID, models.FormStatusClosed).
+		Count(&count).Error
+	return count, err
+}
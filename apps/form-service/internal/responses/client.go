@@ -0,0 +1,117 @@
+// Package responses calls the internal API of the response service, which
+// stores the responses of forms, to select and delete them in bulk
+package responses
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+)
+
+// ErrNotConfigured is returned when no response service URL is set
+var ErrNotConfigured = errors.New("response service is not configured")
+
+// Match is how many responses of a form match a filter, out of all of them,
+// with the first of those matching
+type Match struct {
+	Matched   int                      `json:"matched"`
+	Total     int                      `json:"total"`
+	Responses []models.DeletedResponse `json:"responses"`
+}
+
+// Store selects and deletes the responses of forms
+type Store interface {
+	// Match counts the responses of a form matching filter, returning up
+	// to limit of them, oldest first
+	Match(ctx context.Context, formID string, filter models.ResponseFilter, limit int) (*Match, error)
+	// Delete deletes responses of a form by ID, returning how many were
+	// deleted. IDs deleted already are skipped, so a batch can be deleted
+	// again.
+	Delete(ctx context.Context, formID string, ids []string) (int, error)
+}
+
+// Client calls the internal endpoints of the response service
+type Client struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// NewClient creates a client of the response service at baseURL,
+// authenticating with apiKey as X-API-Key when it is set
+func NewClient(baseURL, apiKey string) *Client {
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Match posts the filter to /internal/forms/{id}/responses/match
+func (c *Client) Match(ctx context.Context, formID string, filter models.ResponseFilter, limit int) (*Match, error) {
+	var body struct {
+		Data Match `json:"data"`
+	}
+	err := c.post(ctx, formID, "match", map[string]interface{}{"filter": filter, "limit": limit}, &body)
+	if err != nil {
+		return nil, err
+	}
+	return &body.Data, nil
+}
+
+// Delete posts the IDs to /internal/forms/{id}/responses/delete
+func (c *Client) Delete(ctx context.Context, formID string, ids []string) (int, error) {
+	var body struct {
+		Data struct {
+			Deleted int `json:"deleted"`
+		} `json:"data"`
+	}
+	if err := c.post(ctx, formID, "delete", map[string]interface{}{"response_ids": ids}, &body); err != nil {
+		return 0, err
+	}
+	return body.Data.Deleted, nil
+}
+
+// post sends request to the endpoint of the responses of a form and decodes
+// the body of its answer into v
+func (c *Client) post(ctx context.Context, formID, endpoint string, request, v interface{}) error {
+	if c.baseURL == "" {
+		return ErrNotConfigured
+	}
+
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		c.baseURL+"/internal/forms/"+url.PathEscape(formID)+"/responses/"+endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to %s responses of form %s: %w", endpoint, formID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("response service answered %d to %s responses of form %s", resp.StatusCode, endpoint, formID)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("invalid answer to %s responses of form %s: %w", endpoint, formID, err)
+	}
+	return nil
+}
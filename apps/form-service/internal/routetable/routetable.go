@@ -0,0 +1,166 @@
+// Package routetable records the routes the service registers with gin and
+// publishes them as a manifest. The API Gateway contract tests load the
+// manifest to check that every proxied prefix reaches real routes with the
+// authentication the gateway assumes.
+package routetable
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Auth is the authentication a route requires
+type Auth string
+
+const (
+	// AuthPublic routes ignore credentials
+	AuthPublic Auth = "public"
+	// AuthOptional routes accept anonymous callers but use credentials when sent
+	AuthOptional Auth = "optional"
+	// AuthRequired routes reject anonymous callers
+	AuthRequired Auth = "required"
+	// AuthService routes admit only other services, with the shared token
+	AuthService Auth = "service"
+)
+
+// Route is a registered route
+type Route struct {
+	Method string `json:"method"`
+	// Path is the gin path template, e.g. /api/v1/forms/:id
+	Path string `json:"path"`
+	Auth Auth   `json:"auth"`
+}
+
+// Manifest is the route table of a service
+type Manifest struct {
+	Service string  `json:"service"`
+	Routes  []Route `json:"routes"`
+}
+
+// Table records the routes registered through its groups
+type Table struct {
+	service    string
+	routes     []Route
+	middleware map[string]Auth
+}
+
+// New creates an empty table for service
+func New(service string) *Table {
+	return &Table{service: service, middleware: make(map[string]Auth)}
+}
+
+// Middleware declares that routes using handlers created like handler
+// require auth. Closures returned by the same constructor share their name,
+// so any instance identifies the middleware.
+func (t *Table) Middleware(handler gin.HandlerFunc, auth Auth) {
+	t.middleware[handlerName(handler)] = auth
+}
+
+// Wrap returns a group recording the routes registered on group
+func (t *Table) Wrap(group *gin.RouterGroup) *Group {
+	return &Group{table: t, group: group}
+}
+
+// Manifest returns the recorded routes sorted by path and method
+func (t *Table) Manifest() Manifest {
+	routes := append([]Route(nil), t.routes...)
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return Manifest{Service: t.service, Routes: routes}
+}
+
+// WriteFile writes the manifest as indented JSON
+func (t *Table) WriteFile(name string) error {
+	data, err := json.MarshalIndent(t.Manifest(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, append(data, '\n'), 0o644)
+}
+
+// Handler serves the manifest, for the /debug/routes endpoint of dev builds
+func (t *Table) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, t.Manifest())
+	}
+}
+
+func (t *Table) record(method, fullPath string, handlers []gin.HandlerFunc) {
+	auth := AuthPublic
+	for _, handler := range handlers {
+		if a, ok := t.middleware[handlerName(handler)]; ok {
+			auth = a
+		}
+	}
+	t.routes = append(t.routes, Route{Method: method, Path: fullPath, Auth: auth})
+}
+
+// Group is a gin router group whose routes are recorded in a Table
+type Group struct {
+	table    *Table
+	group    *gin.RouterGroup
+	handlers []gin.HandlerFunc
+}
+
+// Group creates a sub group, like gin.RouterGroup.Group
+func (g *Group) Group(relativePath string, handlers ...gin.HandlerFunc) *Group {
+	return &Group{
+		table:    g.table,
+		group:    g.group.Group(relativePath, handlers...),
+		handlers: append(append([]gin.HandlerFunc(nil), g.handlers...), handlers...),
+	}
+}
+
+// Handle registers and records a route
+func (g *Group) Handle(method, relativePath string, handlers ...gin.HandlerFunc) {
+	g.group.Handle(method, relativePath, handlers...)
+	g.table.record(method, joinPaths(g.group.BasePath(), relativePath), append(append([]gin.HandlerFunc(nil), g.handlers...), handlers...))
+}
+
+// GET registers and records a GET route
+func (g *Group) GET(relativePath string, handlers ...gin.HandlerFunc) {
+	g.Handle(http.MethodGet, relativePath, handlers...)
+}
+
+// POST registers and records a POST route
+func (g *Group) POST(relativePath string, handlers ...gin.HandlerFunc) {
+	g.Handle(http.MethodPost, relativePath, handlers...)
+}
+
+// PUT registers and records a PUT route
+func (g *Group) PUT(relativePath string, handlers ...gin.HandlerFunc) {
+	g.Handle(http.MethodPut, relativePath, handlers...)
+}
+
+// DELETE registers and records a DELETE route
+func (g *Group) DELETE(relativePath string, handlers ...gin.HandlerFunc) {
+	g.Handle(http.MethodDelete, relativePath, handlers...)
+}
+
+// joinPaths joins paths the way gin does, keeping a trailing slash
+func joinPaths(base, relative string) string {
+	if relative == "" {
+		return base
+	}
+	joined := path.Join(base, relative)
+	if strings.HasSuffix(relative, "/") && !strings.HasSuffix(joined, "/") {
+		joined += "/"
+	}
+	return joined
+}
+
+func handlerName(handler gin.HandlerFunc) string {
+	return runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name()
+}
@@ -0,0 +1,56 @@
+package routetable
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func requireUser() gin.HandlerFunc {
+	return func(c *gin.Context) { c.Next() }
+}
+
+func allowAnonymous() gin.HandlerFunc {
+	return func(c *gin.Context) { c.Next() }
+}
+
+func handle(c *gin.Context) {}
+
+func TestRecordsPathsAndAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	table := New("form-service")
+	table.Middleware(requireUser(), AuthRequired)
+	table.Middleware(allowAnonymous(), AuthOptional)
+
+	root := table.Wrap(&router.RouterGroup)
+	root.GET("/health", handle)
+	forms := root.Group("/api/v1").Group("/forms")
+	forms.POST("", requireUser(), handle)
+	forms.GET("/:id", allowAnonymous(), handle)
+	admin := root.Group("/admin", requireUser())
+	admin.DELETE("/cache/", handle)
+
+	want := []Route{
+		{Method: http.MethodDelete, Path: "/admin/cache/", Auth: AuthRequired},
+		{Method: http.MethodPost, Path: "/api/v1/forms", Auth: AuthRequired},
+		{Method: http.MethodGet, Path: "/api/v1/forms/:id", Auth: AuthOptional},
+		{Method: http.MethodGet, Path: "/health", Auth: AuthPublic},
+	}
+	manifest := table.Manifest()
+	if manifest.Service != "form-service" || len(manifest.Routes) != len(want) {
+		t.Fatalf("manifest = %+v", manifest)
+	}
+	for i, route := range manifest.Routes {
+		if route != want[i] {
+			t.Errorf("route %d = %+v, want %+v", i, route, want[i])
+		}
+	}
+
+	// The routes are registered with gin as well
+	if len(router.Routes()) != len(want) {
+		t.Errorf("gin has %d routes, want %d", len(router.Routes()), len(want))
+	}
+}
@@ -0,0 +1,164 @@
+// Package scanner provides antivirus scanning for uploaded files
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// Verdict is the outcome of scanning a file
+type Verdict string
+
+const (
+	VerdictPending     Verdict = "pending"
+	VerdictClean       Verdict = "clean"
+	VerdictInfected    Verdict = "infected"
+	VerdictUnscannable Verdict = "unscannable"
+)
+
+// Result describes a completed scan
+type Result struct {
+	Verdict Verdict
+	// Signature names the detected threat when Verdict is VerdictInfected
+	Signature string
+}
+
+// Scanner inspects file contents for malware
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) (Result, error)
+	Name() string
+}
+
+// Config holds scanner settings
+type Config struct {
+	Driver  string // "noop" or "clamav"
+	Address string // clamd TCP address, e.g. "localhost:3310"
+	Timeout time.Duration
+}
+
+// New creates the scanner selected by cfg.Driver
+func New(cfg Config) (Scanner, error) {
+	switch cfg.Driver {
+	case "", "noop":
+		return NoopScanner{}, nil
+	case "clamav":
+		return NewClamAVScanner(cfg.Address, cfg.Timeout), nil
+	default:
+		return nil, fmt.Errorf("unsupported scanner driver: %s", cfg.Driver)
+	}
+}
+
+// NoopScanner reports every file as clean. It is meant for development.
+type NoopScanner struct{}
+
+// Scan drains the reader and reports the file as clean
+func (NoopScanner) Scan(ctx context.Context, r io.Reader) (Result, error) {
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return Result{}, err
+	}
+	return Result{Verdict: VerdictClean}, nil
+}
+
+// Name returns the scanner name
+func (NoopScanner) Name() string { return "noop" }
+
+// clamdChunkSize is the size of each INSTREAM chunk sent to clamd
+const clamdChunkSize = 64 * 1024
+
+// ClamAVScanner streams files to a clamd daemon over TCP using the INSTREAM command
+type ClamAVScanner struct {
+	address string
+	timeout time.Duration
+}
+
+// NewClamAVScanner creates a new clamd scanner
+func NewClamAVScanner(address string, timeout time.Duration) *ClamAVScanner {
+	if address == "" {
+		address = "localhost:3310"
+	}
+	if timeout <= 0 {
+		timeout = 2 * time.Minute
+	}
+	return &ClamAVScanner{address: address, timeout: timeout}
+}
+
+// Name returns the scanner name
+func (s *ClamAVScanner) Name() string { return "clamav" }
+
+// Scan sends the file to clamd and parses its reply. Files clamd refuses
+// (size limits, unsupported archives) are reported as unscannable.
+func (s *ClamAVScanner) Scan(ctx context.Context, r io.Reader) (Result, error) {
+	dialer := net.Dialer{Timeout: 10 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", s.address)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(s.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return Result{}, fmt.Errorf("failed to start clamd stream: %w", err)
+	}
+
+	buf := make([]byte, clamdChunkSize)
+	size := make([]byte, 4)
+	for {
+		n, readErr := r.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err := conn.Write(size); err != nil {
+				return Result{}, fmt.Errorf("failed to stream to clamd: %w", err)
+			}
+			if _, err := conn.Write(buf[:n]); err != nil {
+				return Result{}, fmt.Errorf("failed to stream to clamd: %w", err)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return Result{}, fmt.Errorf("failed to read file: %w", readErr)
+		}
+	}
+
+	// A zero-length chunk terminates the stream
+	if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return Result{}, fmt.Errorf("failed to finish clamd stream: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && err != io.EOF {
+		return Result{}, fmt.Errorf("failed to read clamd reply: %w", err)
+	}
+
+	return parseClamdReply(reply), nil
+}
+
+// parseClamdReply interprets replies such as "stream: OK",
+// "stream: Eicar-Signature FOUND" or "INSTREAM size limit exceeded. ERROR"
+func parseClamdReply(reply string) Result {
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+	switch {
+	case strings.HasSuffix(reply, " OK"):
+		return Result{Verdict: VerdictClean}
+	case strings.HasSuffix(reply, " FOUND"):
+		signature := strings.TrimSuffix(reply, " FOUND")
+		if i := strings.Index(signature, ": "); i >= 0 {
+			signature = signature[i+2:]
+		}
+		return Result{Verdict: VerdictInfected, Signature: signature}
+	default:
+		return Result{Verdict: VerdictUnscannable, Signature: reply}
+	}
+}
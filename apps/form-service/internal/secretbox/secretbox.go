@@ -0,0 +1,71 @@
+// Package secretbox encrypts the secrets the form service stores on behalf
+// of form owners, such as the webhook URLs of their chat channels, so that
+// a database dump does not leak them. A sealed value is the version prefix
+// and the base64url encoded nonce and AES-256-GCM ciphertext of the value,
+// under a key derived from the configured secret with SHA-256.
+package secretbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// prefix marks the format of sealed values, so the scheme can change
+// without ambiguity
+const prefix = "v1."
+
+// ErrInvalidSealedValue is returned for values that are malformed or not
+// sealed with the secret
+var ErrInvalidSealedValue = errors.New("invalid sealed value")
+
+// Seal encrypts plaintext with secret
+func Seal(secret, plaintext string) (string, error) {
+	aead, err := newAEAD(secret)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return prefix + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a value sealed with secret
+func Open(secret, sealed string) (string, error) {
+	encoded, ok := strings.CutPrefix(sealed, prefix)
+	if !ok {
+		return "", ErrInvalidSealedValue
+	}
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrInvalidSealedValue
+	}
+	aead, err := newAEAD(secret)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < aead.NonceSize() {
+		return "", ErrInvalidSealedValue
+	}
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return "", ErrInvalidSealedValue
+	}
+	return string(plaintext), nil
+}
+
+func newAEAD(secret string) (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
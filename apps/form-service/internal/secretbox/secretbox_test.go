@@ -0,0 +1,43 @@
+package secretbox
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSealOpen(t *testing.T) {
+	const url = "https://hooks.slack.com/services/T000/B000/XXXXXXXX"
+
+	sealed, err := Seal("secret", url)
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if strings.Contains(sealed, "hooks.slack.com") {
+		t.Errorf("sealed value %q shows the plaintext", sealed)
+	}
+	again, _ := Seal("secret", url)
+	if again == sealed {
+		t.Error("sealing twice gave the same value, want a fresh nonce")
+	}
+
+	got, err := Open("secret", sealed)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if got != url {
+		t.Errorf("Open = %q, want %q", got, url)
+	}
+
+	for name, tc := range map[string]struct{ secret, sealed string }{
+		"other secret": {"other", sealed},
+		"tampered":     {"secret", sealed[:len(sealed)-2] + "xx"},
+		"no prefix":    {"secret", strings.TrimPrefix(sealed, prefix)},
+		"truncated":    {"secret", prefix + "AAAA"},
+		"not base64":   {"secret", prefix + "!!"},
+	} {
+		if _, err := Open(tc.secret, tc.sealed); !errors.Is(err, ErrInvalidSealedValue) {
+			t.Errorf("%s: error = %v, want ErrInvalidSealedValue", name, err)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/access"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+)
+
+const (
+	// DefaultActivityLimit and MaxActivityLimit bound a page of the activity
+	// feed
+	DefaultActivityLimit = 50
+	MaxActivityLimit     = 200
+)
+
+// ActivityLog records the changes editors make to the definition of forms
+// in the transaction of each change, keeping the latest of each form, and
+// pushes them to the builders open on the form once committed
+type ActivityLog struct {
+	keep      int
+	publisher events.ActivityPublisher
+}
+
+// NewActivityLog creates an activity log keeping the keep latest activities
+// of each form, or all of them when keep is 0. Activities are pushed to
+// publisher, which may be nil, and so are merged patches when it is an
+// events.PatchPublisher too.
+func NewActivityLog(keep int, publisher events.ActivityPublisher) *ActivityLog {
+	return &ActivityLog{keep: keep, publisher: publisher}
+}
+
+// record runs change with a context carrying activity, which the repository
+// records in the transaction of the change, and publishes the activity once
+// the change committed. A nil log or activity only runs the change.
+func (l *ActivityLog) record(ctx context.Context, activity *models.FormActivity, change func(ctx context.Context) error) error {
+	if l == nil || activity == nil {
+		return change(ctx)
+	}
+	if err := change(repository.WithActivity(ctx, activity, l.keep)); err != nil {
+		return err
+	}
+	if l.publisher != nil {
+		if err := l.publisher.PublishActivity(ctx, activity); err != nil {
+			log.Printf("Failed to publish activity %s of form %s: %v", activity.Action, activity.FormID, err)
+		}
+	}
+	return nil
+}
+
+// publishPatch pushes a merged patch to the builders open on its form, when
+// the publisher of the log publishes patches
+func (l *ActivityLog) publishPatch(ctx context.Context, patch *models.FormPatch) {
+	if l == nil {
+		return
+	}
+	publisher, ok := l.publisher.(events.PatchPublisher)
+	if !ok {
+		return
+	}
+	if err := publisher.PublishPatch(ctx, patch); err != nil {
+		log.Printf("Failed to publish patch %d of form %s: %v", patch.Version, patch.FormID, err)
+	}
+}
+
+// formActivity returns the activity of a change by actor to the form itself,
+// nil for an update that changed nothing
+func formActivity(formID, actor uuid.UUID, action models.ActivityAction, changes []string) *models.FormActivity {
+	if action == models.ActivityFormUpdated && len(changes) == 0 {
+		return nil
+	}
+	return &models.FormActivity{
+		FormID:     formID,
+		ActorID:    actor,
+		Action:     action,
+		TargetType: models.ActivityTargetForm,
+		TargetID:   formID,
+		Changes:    changes,
+	}
+}
+
+// questionActivity returns the activity of a change by actor to a question,
+// nil for an update that changed nothing
+func questionActivity(question *models.Question, actor uuid.UUID, action models.ActivityAction, changes []string) *models.FormActivity {
+	if action == models.ActivityQuestionUpdated && len(changes) == 0 {
+		return nil
+	}
+	return &models.FormActivity{
+		FormID:     question.FormID,
+		ActorID:    actor,
+		Action:     action,
+		TargetType: models.ActivityTargetQuestion,
+		TargetID:   question.ID,
+		Changes:    changes,
+	}
+}
+
+// formChanges names the fields of the definition of a form that differ
+// between before and after. Only names are kept, never values.
+func formChanges(before, after *models.Form) []string {
+	var changes []string
+	if before.Title != after.Title {
+		changes = append(changes, "title")
+	}
+	if before.Description != after.Description {
+		changes = append(changes, "description")
+	}
+	if slugOf(before) != slugOf(after) {
+		changes = append(changes, "slug")
+	}
+	if !jsonEqual(before.Settings, after.Settings) {
+		changes = append(changes, "settings")
+	}
+	if !reflect.DeepEqual(before.RetentionDays, after.RetentionDays) {
+		changes = append(changes, "retention_days")
+	}
+	if !jsonEqual(before.Rules, after.Rules) {
+		changes = append(changes, "rules")
+	}
+	return changes
+}
+
+// questionChanges names the fields of a question that differ between before
+// and after
+func questionChanges(before, after *models.Question) []string {
+	var changes []string
+	if before.Type != after.Type {
+		changes = append(changes, "type")
+	}
+	if before.Title != after.Title {
+		changes = append(changes, "title")
+	}
+	if before.Description != after.Description {
+		changes = append(changes, "description")
+	}
+	if before.Order != after.Order {
+		changes = append(changes, "order")
+	}
+	if !jsonEqual(before.Options, after.Options) {
+		changes = append(changes, "options")
+	}
+	if !jsonEqual(before.Validation, after.Validation) {
+		changes = append(changes, "validation")
+	}
+	if !jsonEqual(before.Display, after.Display) {
+		changes = append(changes, "display")
+	}
+	if before.ResultsVisibility != after.ResultsVisibility {
+		changes = append(changes, "results_visibility")
+	}
+	return changes
+}
+
+// jsonEqual reports whether two JSON documents hold the same value, whatever
+// their formatting. The database normalizes the JSON it stores.
+func jsonEqual(a, b []byte) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return len(a) == len(b)
+	}
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return string(a) == string(b)
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// ActivityService defines the interface for reading the activity feed of
+// forms
+type ActivityService interface {
+	ListActivity(ctx context.Context, formID, userID uuid.UUID, query ActivityQuery) (*ActivityPage, error)
+}
+
+// ActivityQuery selects a page of the activity feed of a form
+type ActivityQuery struct {
+	// Cursor is the NextCursor of the previous page, empty for the newest
+	// activities
+	Cursor string
+	Limit  int
+	// Actions keeps the activities of these actions, all when empty
+	Actions []models.ActivityAction
+}
+
+// ActivityPage is a page of the activity feed of a form, newest first.
+// NextCursor continues after the page and is empty on the last one.
+type ActivityPage struct {
+	Activities []*models.FormActivity `json:"activities"`
+	NextCursor string                 `json:"next_cursor,omitempty"`
+	HasMore    bool                   `json:"has_more"`
+}
+
+// activityService implements ActivityService interface
+type activityService struct {
+	activities repository.ActivityRepository
+	guard      formGuard
+}
+
+// NewActivityService creates a new activity service instance. The feed of
+// a form is read by its owner and collaborators.
+func NewActivityService(activities repository.ActivityRepository, formRepo repository.FormRepository, collaboratorRepo repository.CollaboratorRepository, orgRepo repository.OrganizationRepository) ActivityService {
+	return &activityService{
+		activities: activities,
+		guard:      formGuard{forms: formRepo, collaborators: collaboratorRepo, orgs: organizationScope{orgs: orgRepo}},
+	}
+}
+
+// ListActivity returns a page of the activity feed of the form
+func (s *activityService) ListActivity(ctx context.Context, formID, userID uuid.UUID, query ActivityQuery) (*ActivityPage, error) {
+	before, err := decodeActivityCursor(query.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = DefaultActivityLimit
+	}
+	if limit > MaxActivityLimit {
+		limit = MaxActivityLimit
+	}
+
+	if _, err := s.guard.authorize(ctx, formID, userID, access.View); err != nil {
+		return nil, err
+	}
+
+	// One more than the page tells whether there is another
+	activities, err := s.activities.List(ctx, formID, query.Actions, before, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list activity: %w", err)
+	}
+
+	page := &ActivityPage{Activities: activities}
+	if len(activities) > limit {
+		page.Activities = activities[:limit]
+		page.HasMore = true
+		last := page.Activities[limit-1]
+		page.NextCursor = encodeActivityCursor(repository.ActivityCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	if page.Activities == nil {
+		page.Activities = []*models.FormActivity{}
+	}
+	return page, nil
+}
+
+// encodeActivityCursor encodes the position of an activity as an opaque
+// cursor
+func encodeActivityCursor(c repository.ActivityCursor) string {
+	raw := strconv.FormatInt(c.CreatedAt.UnixMicro(), 10) + ":" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeActivityCursor decodes a cursor of encodeActivityCursor, the empty
+// cursor being the newest activity
+func decodeActivityCursor(cursor string) (repository.ActivityCursor, error) {
+	if cursor == "" {
+		return repository.ActivityCursor{}, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return repository.ActivityCursor{}, ErrInvalidCursor
+	}
+	micros, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return repository.ActivityCursor{}, ErrInvalidCursor
+	}
+	usec, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return repository.ActivityCursor{}, ErrInvalidCursor
+	}
+	activityID, err := uuid.Parse(id)
+	if err != nil {
+		return repository.ActivityCursor{}, ErrInvalidCursor
+	}
+	return repository.ActivityCursor{CreatedAt: time.UnixMicro(usec).UTC(), ID: activityID}, nil
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+)
+
+// memoryActivities keeps the activities the changes made under it carry, as
+// the repositories record them in the transaction of each change
+type memoryActivities struct {
+	mu         sync.Mutex
+	clock      time.Time
+	activities []*models.FormActivity
+}
+
+func (r *memoryActivities) record(ctx context.Context) {
+	activity := repository.ActivityFrom(ctx)
+	if activity == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clock = r.clock.Add(time.Second)
+	activity.ID, activity.CreatedAt = uuid.New(), r.clock
+	r.activities = append(r.activities, activity)
+}
+
+func (r *memoryActivities) List(_ context.Context, formID uuid.UUID, actions []models.ActivityAction, before repository.ActivityCursor, limit int) ([]*models.FormActivity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var activities []*models.FormActivity
+	for _, activity := range r.activities {
+		if activity.FormID != formID || (len(actions) > 0 && !containsAction(actions, activity.Action)) {
+			continue
+		}
+		if !before.CreatedAt.IsZero() && !activity.CreatedAt.Before(before.CreatedAt) {
+			continue
+		}
+		activities = append(activities, activity)
+	}
+	sort.Slice(activities, func(i, j int) bool { return activities[i].CreatedAt.After(activities[j].CreatedAt) })
+	if len(activities) > limit {
+		activities = activities[:limit]
+	}
+	return activities, nil
+}
+
+func containsAction(actions []models.ActivityAction, action models.ActivityAction) bool {
+	for _, a := range actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// activityForms records the activity of the changes to forms
+type activityForms struct {
+	*memoryFormRepository
+	log *memoryActivities
+}
+
+func (r activityForms) Create(ctx context.Context, form *models.Form) error {
+	r.log.record(ctx)
+	return r.memoryFormRepository.Create(ctx, form)
+}
+
+func (r activityForms) Update(ctx context.Context, form *models.Form) error {
+	r.log.record(ctx)
+	return r.memoryFormRepository.Update(ctx, form)
+}
+
+func (r activityForms) ChangeSlug(ctx context.Context, form *models.Form, previous string, redirectUntil time.Time) error {
+	r.log.record(ctx)
+	return r.memoryFormRepository.ChangeSlug(ctx, form, previous, redirectUntil)
+}
+
+// activityQuestions records the activity of the changes to questions
+type activityQuestions struct {
+	*memoryQuestions
+	log *memoryActivities
+}
+
+func (r activityQuestions) Create(ctx context.Context, question *models.Question) error {
+	r.log.record(ctx)
+	return r.memoryQuestions.Create(ctx, question)
+}
+
+func (r activityQuestions) Update(ctx context.Context, question *models.Question) error {
+	r.log.record(ctx)
+	return r.memoryQuestions.Update(ctx, question)
+}
+
+func (r activityQuestions) Delete(ctx context.Context, id uuid.UUID) error {
+	r.log.record(ctx)
+	return r.memoryQuestions.Delete(ctx, id)
+}
+
+func (r activityQuestions) UpdateOrder(ctx context.Context, formID uuid.UUID, orders []repository.QuestionOrder) error {
+	r.log.record(ctx)
+	return r.memoryQuestions.UpdateOrder(ctx, formID, orders)
+}
+
+func (r activityQuestions) RenameKey(ctx context.Context, question *models.Question, alias *models.QuestionKeyAlias) error {
+	r.log.record(ctx)
+	return r.memoryQuestions.RenameKey(ctx, question, alias)
+}
+
+// recordingActivityPublisher keeps the activities pushed to builders
+type recordingActivityPublisher struct {
+	published []models.ActivityAction
+}
+
+func (p *recordingActivityPublisher) PublishActivity(_ context.Context, activity *models.FormActivity) error {
+	p.published = append(p.published, activity.Action)
+	return nil
+}
+
+func TestActivityFeed(t *testing.T) {
+	ctx := context.Background()
+	log := &memoryActivities{clock: time.Now()}
+	repos := newMemoryStore(time.Now())
+	forms := activityForms{memoryFormRepository: repos.forms, log: log}
+	questions := activityQuestions{memoryQuestions: repos.questions, log: log}
+	publisher := &recordingActivityPublisher{}
+	svc := NewFormService(forms, questions, repos.collaborators, repos.orgs, events.LogPublisher{}, events.LogAuditor{}, nil, NewActivityLog(0, publisher), nil, nil)
+	feed := NewActivityService(log, forms, repos.collaborators, repos.orgs)
+	owner := uuid.New()
+
+	form, err := svc.CreateForm(ctx, owner, CreateFormRequest{Title: "Survey"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	title, description := "Secret launch survey", "Tell us"
+	if _, err := svc.UpdateForm(ctx, form.ID, owner, UpdateFormRequest{Title: &title, Description: &description}); err != nil {
+		t.Fatal(err)
+	}
+	// An update that changes nothing is not recorded
+	if _, err := svc.UpdateForm(ctx, form.ID, owner, UpdateFormRequest{Title: &title}); err != nil {
+		t.Fatal(err)
+	}
+	first, err := svc.AddQuestion(ctx, form.ID, owner, AddQuestionRequest{Type: models.QuestionTypeText, Title: "Name", Order: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := svc.AddQuestion(ctx, form.ID, owner, AddQuestionRequest{Type: models.QuestionTypeText, Title: "Email", Order: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	renamed := "Full name"
+	if _, err := svc.UpdateQuestion(ctx, first.ID, owner, UpdateQuestionRequest{Title: &renamed}); err != nil {
+		t.Fatal(err)
+	}
+	reorder := ReorderQuestionsRequest{QuestionOrders: []QuestionOrder{{ID: first.ID, Order: 2}, {ID: second.ID, Order: 1}}}
+	if err := svc.ReorderQuestions(ctx, form.ID, owner, reorder); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.DeleteQuestion(ctx, second.ID, owner); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []models.ActivityAction{
+		models.ActivityQuestionDeleted,
+		models.ActivityQuestionsReordered,
+		models.ActivityQuestionUpdated,
+		models.ActivityQuestionAdded,
+		models.ActivityQuestionAdded,
+		models.ActivityFormUpdated,
+		models.ActivityFormCreated,
+	}
+
+	// Pages of 3 walk the feed newest first
+	var got []*models.FormActivity
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > len(want) {
+			t.Fatal("feed did not end")
+		}
+		page, err := feed.ListActivity(ctx, form.ID, owner, ActivityQuery{Cursor: cursor, Limit: 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, page.Activities...)
+		if !page.HasMore {
+			if page.NextCursor != "" {
+				t.Errorf("last page has cursor %q", page.NextCursor)
+			}
+			break
+		}
+		cursor = page.NextCursor
+	}
+	var actions []models.ActivityAction
+	for _, activity := range got {
+		actions = append(actions, activity.Action)
+		if activity.ActorID != owner {
+			t.Errorf("%s by %s, want the owner", activity.Action, activity.ActorID)
+		}
+	}
+	if !reflect.DeepEqual(actions, want) {
+		t.Errorf("feed = %v, want %v", actions, want)
+	}
+	if !reflect.DeepEqual(publisher.published, reverseActions(want)) {
+		t.Errorf("published = %v, want every activity in order", publisher.published)
+	}
+
+	// Updates name the changed fields, never their values
+	update := got[5]
+	if !reflect.DeepEqual([]string(update.Changes), []string{"title", "description"}) {
+		t.Errorf("form update changes = %v, want title and description", update.Changes)
+	}
+	for _, activity := range got {
+		for _, change := range activity.Changes {
+			if strings.Contains(change, "Secret") || strings.Contains(change, "Full name") {
+				t.Errorf("%s changes carry a value: %v", activity.Action, activity.Changes)
+			}
+		}
+	}
+	if question := got[2]; question.TargetType != models.ActivityTargetQuestion || question.TargetID != first.ID || !reflect.DeepEqual([]string(question.Changes), []string{"title"}) {
+		t.Errorf("question update = %+v, want the title of the first question", question)
+	}
+
+	// The feed filters by action
+	page, err := feed.ListActivity(ctx, form.ID, owner, ActivityQuery{Actions: []models.ActivityAction{models.ActivityQuestionAdded}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Activities) != 2 || page.HasMore {
+		t.Errorf("question.added page = %d activities, has more %v; want the 2 questions", len(page.Activities), page.HasMore)
+	}
+
+	if _, err := feed.ListActivity(ctx, form.ID, owner, ActivityQuery{Cursor: "not a cursor"}); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("invalid cursor: err = %v, want ErrInvalidCursor", err)
+	}
+	if _, err := feed.ListActivity(ctx, form.ID, uuid.New(), ActivityQuery{}); !errors.Is(err, ErrNotFormOwner) {
+		t.Errorf("stranger read the feed: err = %v, want ErrNotFormOwner", err)
+	}
+}
+
+func reverseActions(actions []models.ActivityAction) []models.ActivityAction {
+	reversed := make([]models.ActivityAction, len(actions))
+	for i, action := range actions {
+		reversed[len(actions)-1-i] = action
+	}
+	return reversed
+}
+
+func TestActivityCursor(t *testing.T) {
+	cursor := repository.ActivityCursor{CreatedAt: time.Date(2026, 3, 1, 12, 0, 0, 123456000, time.UTC), ID: uuid.New()}
+	decoded, err := decodeActivityCursor(encodeActivityCursor(cursor))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.CreatedAt.Equal(cursor.CreatedAt) || decoded.ID != cursor.ID {
+		t.Errorf("cursor = %+v, want %+v", decoded, cursor)
+	}
+	if empty, err := decodeActivityCursor(""); err != nil || !empty.CreatedAt.IsZero() {
+		t.Errorf("empty cursor = %+v, %v; want the newest activity", empty, err)
+	}
+}
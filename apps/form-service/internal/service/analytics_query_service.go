@@ -0,0 +1,212 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/access"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/analytics"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+)
+
+// ErrQueryTooBroad is returned for analytics queries filtering or grouping
+// on a free-text question with too many distinct answers
+var ErrQueryTooBroad = errors.New("analytics query too broad")
+
+// AnalyticsQueryService defines the interface for the drill-down queries of
+// the users who may view a form over its responses
+type AnalyticsQueryService interface {
+	Query(ctx context.Context, formID, userID uuid.UUID, query analytics.Query) (*analytics.QueryResult, error)
+	QuestionTimings(ctx context.Context, formID, userID uuid.UUID) (*analytics.TimingReport, error)
+}
+
+// TimingConfig bounds the timing reports of forms
+type TimingConfig struct {
+	// SampleLimit bounds the latest submissions whose timings are
+	// aggregated, and the abandoned drafts read
+	SampleLimit int
+	// AbandonAfter is how long a draft is left idle before it counts as
+	// abandoned
+	AbandonAfter time.Duration
+}
+
+// analyticsQueryService implements AnalyticsQueryService interface
+type analyticsQueryService struct {
+	guard        formGuard
+	questionRepo repository.QuestionRepository
+	querier      analytics.Querier
+	// maxDistinct bounds the distinct answers of the free-text questions
+	// queries filter or group on
+	maxDistinct int
+	timings     analytics.TimingReader
+	drafts      repository.DraftRepository
+	timing      TimingConfig
+}
+
+// NewAnalyticsQueryService creates a new analytics query service instance
+// running queries with querier. Queries filtering or grouping on a
+// free-text question with more than maxDistinct distinct answers are
+// refused, their conditions matching too few rows to be worth it. Timing
+// reports aggregate the timings read from timings and the abandoned drafts
+// of drafts.
+func NewAnalyticsQueryService(formRepo repository.FormRepository, questionRepo repository.QuestionRepository, collaboratorRepo repository.CollaboratorRepository, orgRepo repository.OrganizationRepository, querier analytics.Querier, maxDistinct int, timings analytics.TimingReader, drafts repository.DraftRepository, timing TimingConfig) AnalyticsQueryService {
+	return &analyticsQueryService{
+		guard:        formGuard{forms: formRepo, collaborators: collaboratorRepo, orgs: organizationScope{orgs: orgRepo}},
+		questionRepo: questionRepo,
+		querier:      querier,
+		maxDistinct:  maxDistinct,
+		timings:      timings,
+		drafts:       drafts,
+		timing:       timing,
+	}
+}
+
+// Query runs a query over the responses of a form userID may view. Every
+// question of the query must be a question of the form, by ID or by key,
+// current or former. The answers projected under any key of a question are
+// counted with it.
+func (s *analyticsQueryService) Query(ctx context.Context, formID, userID uuid.UUID, query analytics.Query) (*analytics.QueryResult, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	form, err := s.guard.authorize(ctx, formID, userID, access.View)
+	if err != nil {
+		return nil, err
+	}
+	if s.querier == nil {
+		return nil, analytics.ErrNotConfigured
+	}
+
+	questions, err := s.questionRepo.GetByFormID(ctx, form.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get questions: %w", err)
+	}
+	keys, err := questionKeys(ctx, s.questionRepo, questions)
+	if err != nil {
+		return nil, err
+	}
+	byRef := make(map[string]*models.Question, len(questions))
+	for _, question := range questions {
+		byRef[question.ID.String()] = question
+		for _, key := range keys[question.ID] {
+			byRef[key] = question
+		}
+	}
+
+	// The counted question is exempt: its answers are only read, not
+	// compared
+	query.Refs = make(map[string]analytics.QuestionRef)
+	checked := make(map[uuid.UUID]bool)
+	for i, ref := range query.Questions() {
+		question, ok := byRef[ref]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is not a question of the form", analytics.ErrInvalidQuery, ref)
+		}
+		resolved := analytics.QuestionRef{ID: question.ID.String(), Keys: keys[question.ID]}
+		query.Refs[ref] = resolved
+		if i == 0 || !freeText(question.Type) || checked[question.ID] {
+			continue
+		}
+		checked[question.ID] = true
+		distinct, err := s.querier.DistinctAnswers(ctx, form.ID.String(), resolved, s.maxDistinct)
+		if err != nil {
+			return nil, err
+		}
+		if distinct > s.maxDistinct {
+			return nil, fmt.Errorf("%w: question %s has more than %d distinct answers", ErrQueryTooBroad, ref, s.maxDistinct)
+		}
+	}
+
+	return s.querier.Query(ctx, form.ID.String(), &query)
+}
+
+// QuestionTimings reports the median and 90th percentile of the time spent
+// on each question of a form userID may view, over the timings of its latest
+// submissions, and the rate at which respondents drop off after it. Drop-off
+// is read from the abandoned drafts kept in the database, those of signed-in
+// respondents; the drafts of anonymous respondents only live in the cache.
+func (s *analyticsQueryService) QuestionTimings(ctx context.Context, formID, userID uuid.UUID) (*analytics.TimingReport, error) {
+	form, err := s.guard.authorize(ctx, formID, userID, access.View)
+	if err != nil {
+		return nil, err
+	}
+	if s.timings == nil {
+		return nil, analytics.ErrNotConfigured
+	}
+
+	questions, err := s.questionRepo.GetByFormID(ctx, form.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get questions: %w", err)
+	}
+	sort.SliceStable(questions, func(i, j int) bool { return questions[i].Order < questions[j].Order })
+	questionIDs := make([]string, len(questions))
+	for i, question := range questions {
+		questionIDs[i] = question.ID.String()
+	}
+
+	timings, responses, err := s.timings.FormTimings(ctx, form.ID.String(), s.timing.SampleLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	drafts, err := s.drafts.Abandoned(ctx, form.ID, now.Add(-s.timing.AbandonAfter), now, s.timing.SampleLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list abandoned drafts: %w", err)
+	}
+	abandoned := make([][]string, len(drafts))
+	for i, draft := range drafts {
+		abandoned[i] = answeredQuestions(draft.Answers)
+	}
+
+	report := &analytics.TimingReport{
+		FormID:          form.ID.String(),
+		Responses:       responses,
+		Timed:           len(timings),
+		AbandonedDrafts: len(drafts),
+		AbandonedAfter:  s.timing.AbandonAfter.String(),
+		Questions:       analytics.AggregateTimings(questionIDs, timings, responses, abandoned),
+		GeneratedAt:     now.UTC(),
+	}
+	for i, question := range questions {
+		report.Questions[i].Title = question.Title
+	}
+	return report, nil
+}
+
+// answeredQuestions returns the questions a draft answers: the keys of its
+// answers object whose value is neither null nor empty
+func answeredQuestions(answers []byte) []string {
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(answers, &values); err != nil {
+		return nil
+	}
+	answered := make([]string, 0, len(values))
+	for questionID, value := range values {
+		switch string(bytes.TrimSpace(value)) {
+		case "null", `""`, "[]", "{}":
+			continue
+		}
+		answered = append(answered, questionID)
+	}
+	return answered
+}
+
+// freeText reports whether the answers to questions of the type are typed
+// in by respondents
+func freeText(questionType models.QuestionType) bool {
+	switch questionType {
+	case models.QuestionTypeText, models.QuestionTypeTextarea, models.QuestionTypeEmail:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/analytics"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+)
+
+// fakeQuerier answers queries with an empty result and counts the distinct
+// answers of questions from a map
+type fakeQuerier struct {
+	distinct map[string]int
+	counted  []string
+	queries  []*analytics.Query
+}
+
+func (q *fakeQuerier) Query(_ context.Context, formID string, query *analytics.Query) (*analytics.QueryResult, error) {
+	q.queries = append(q.queries, query)
+	return analytics.Assemble(formID, query.Question, nil), nil
+}
+
+func (q *fakeQuerier) DistinctAnswers(_ context.Context, _ string, question analytics.QuestionRef, limit int) (int, error) {
+	q.counted = append(q.counted, question.ID)
+	if distinct := q.distinct[question.ID]; distinct <= limit {
+		return distinct, nil
+	}
+	return limit + 1, nil
+}
+
+// fakeTimings returns the same timings for every form
+type fakeTimings struct {
+	timings   []map[string]analytics.QuestionTiming
+	responses int64
+	limit     int
+}
+
+func (f *fakeTimings) FormTimings(_ context.Context, _ string, limit int) ([]map[string]analytics.QuestionTiming, int64, error) {
+	f.limit = limit
+	if len(f.timings) > limit {
+		return f.timings[:limit], f.responses, nil
+	}
+	return f.timings, f.responses, nil
+}
+
+func TestAnalyticsQuery(t *testing.T) {
+	ctx := context.Background()
+	repos := newMemoryStore(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	owner := uuid.New()
+	form := repos.createForm(t, &models.Form{UserID: owner, Title: "Survey", Status: models.FormStatusPublished})
+	other := repos.createForm(t, &models.Form{UserID: owner, Title: "Other", Status: models.FormStatusPublished})
+	viewer, invited := uuid.New(), uuid.New()
+	for _, collaborator := range []*models.Collaborator{
+		{FormID: form.ID, UserID: &viewer, Role: models.CollaboratorRoleViewer, Status: models.CollaboratorStatusActive},
+		{FormID: form.ID, UserID: &invited, Role: models.CollaboratorRoleViewer, Status: models.CollaboratorStatusPending},
+	} {
+		if err := repos.collaborators.Create(ctx, collaborator); err != nil {
+			t.Fatal(err)
+		}
+	}
+	add := func(formID uuid.UUID, questionType models.QuestionType) string {
+		question := &models.Question{FormID: formID, Type: questionType, Title: string(questionType), Key: string(questionType)}
+		repos.createQuestions(t, question)
+		return question.ID.String()
+	}
+	radio := add(form.ID, models.QuestionTypeRadio)
+	age := add(form.ID, models.QuestionTypeNumber)
+	city := add(form.ID, models.QuestionTypeText)
+	comment := add(form.ID, models.QuestionTypeTextarea)
+	foreign := add(other.ID, models.QuestionTypeRadio)
+	// The city question was renamed from town
+	repos.questions.aliases = append(repos.questions.aliases, models.QuestionKeyAlias{FormID: form.ID, Key: "town", QuestionID: uuid.MustParse(city)})
+
+	querier := &fakeQuerier{distinct: map[string]int{city: 12, comment: 500}}
+	svc := NewAnalyticsQueryService(repos.forms, repos.questions, repos.collaborators, repos.orgs, querier, 50, nil, nil, TimingConfig{})
+	eq := func(question string) []analytics.Filter {
+		return []analytics.Filter{{Question: question, Operator: analytics.OpEq, Value: json.RawMessage(`"x"`)}}
+	}
+
+	tests := []struct {
+		name    string
+		formID  uuid.UUID
+		userID  uuid.UUID
+		query   analytics.Query
+		want    error
+		counted []string
+	}{
+		{"ungrouped", form.ID, owner, analytics.Query{Question: radio}, nil, nil},
+		{"numeric filter", form.ID, owner, analytics.Query{Question: radio, Filters: []analytics.Filter{{Question: age, Operator: analytics.OpGte, Value: json.RawMessage(`18`)}}}, nil, nil},
+		{"free-text filter with few answers", form.ID, owner, analytics.Query{Question: radio, Filters: eq(city)}, nil, []string{city}},
+		{"free-text filter with many answers", form.ID, owner, analytics.Query{Question: radio, Filters: eq(comment)}, ErrQueryTooBroad, []string{comment}},
+		{"free-text group_by with many answers", form.ID, owner, analytics.Query{Question: radio, GroupBy: &analytics.GroupBy{Question: comment}}, ErrQueryTooBroad, []string{comment}},
+		{"free-text question counted", form.ID, owner, analytics.Query{Question: comment}, nil, nil},
+		{"by key", form.ID, owner, analytics.Query{Question: "radio", Filters: eq("text")}, nil, []string{city}},
+		{"by former key", form.ID, owner, analytics.Query{Question: radio, Filters: eq("town")}, nil, []string{city}},
+		{"question of another form", form.ID, owner, analytics.Query{Question: radio, Filters: eq(foreign)}, analytics.ErrInvalidQuery, nil},
+		{"unknown question", form.ID, owner, analytics.Query{Question: uuid.NewString()}, analytics.ErrInvalidQuery, nil},
+		{"invalid query", form.ID, owner, analytics.Query{Question: radio, Filters: []analytics.Filter{{Question: age, Operator: "like", Value: json.RawMessage(`1`)}}}, analytics.ErrInvalidQuery, nil},
+		{"viewer", form.ID, viewer, analytics.Query{Question: radio}, nil, nil},
+		{"pending invitation", form.ID, invited, analytics.Query{Question: radio}, ErrNotFormOwner, nil},
+		{"stranger", form.ID, uuid.New(), analytics.Query{Question: radio}, ErrNotFormOwner, nil},
+		{"unknown form", uuid.New(), owner, analytics.Query{Question: radio}, ErrFormNotFound, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			querier.counted, querier.queries = nil, nil
+			result, err := svc.Query(ctx, tt.formID, tt.userID, tt.query)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if tt.want == nil && (result == nil || result.FormID != form.ID.String() || len(querier.queries) != 1) {
+				t.Errorf("result = %+v after %d queries, want the result of one query of the form", result, len(querier.queries))
+			}
+			if tt.want != nil && len(querier.queries) != 0 {
+				t.Errorf("a refused query was run")
+			}
+			if len(querier.counted) != len(tt.counted) || (len(tt.counted) > 0 && querier.counted[0] != tt.counted[0]) {
+				t.Errorf("distinct answers counted for %v, want %v", querier.counted, tt.counted)
+			}
+			if tt.want == nil {
+				for _, ref := range querier.queries[0].Questions() {
+					if resolved := querier.queries[0].Refs[ref]; resolved.ID == "" || len(resolved.Keys) == 0 {
+						t.Errorf("%s resolved to %+v, want the question with its keys", ref, resolved)
+					}
+				}
+			}
+		})
+	}
+
+	unconfigured := NewAnalyticsQueryService(repos.forms, repos.questions, repos.collaborators, repos.orgs, nil, 50, nil, nil, TimingConfig{})
+	if _, err := unconfigured.Query(ctx, form.ID, owner, analytics.Query{Question: radio}); !errors.Is(err, analytics.ErrNotConfigured) {
+		t.Errorf("without a projection database: err = %v, want ErrNotConfigured", err)
+	}
+}
+
+func TestAnalyticsQuestionTimings(t *testing.T) {
+	ctx := context.Background()
+	repos := newMemoryStore(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	owner := uuid.New()
+	form := repos.createForm(t, &models.Form{UserID: owner, Title: "Survey", Status: models.FormStatusPublished})
+	var ids []string
+	for i, title := range []string{"Name", "Plan", "Comment"} {
+		question := &models.Question{FormID: form.ID, Type: models.QuestionTypeText, Title: title, Order: i}
+		repos.createQuestions(t, question)
+		ids = append(ids, question.ID.String())
+	}
+
+	now := time.Now()
+	drafts := newMemoryDrafts(time.Now)
+	for i, draft := range []struct {
+		answers string
+		idle    time.Duration
+	}{
+		{fmt.Sprintf(`{%q: "Ada", %q: "pro"}`, ids[0], ids[1]), 48 * time.Hour},
+		{fmt.Sprintf(`{%q: "Bob", %q: ""}`, ids[0], ids[1]), 30 * time.Hour},
+		// Still being filled in, not abandoned yet
+		{fmt.Sprintf(`{%q: "Cy", %q: "team"}`, ids[0], ids[1]), time.Hour},
+	} {
+		if err := drafts.store(&models.ResponseDraft{
+			FormID:    form.ID,
+			OwnerKey:  fmt.Sprintf("user:%d", i),
+			Answers:   []byte(draft.answers),
+			UpdatedAt: now.Add(-draft.idle),
+			ExpiresAt: now.Add(models.DefaultDraftTTL - draft.idle),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	timings := &fakeTimings{responses: 8, timings: []map[string]analytics.QuestionTiming{
+		{ids[0]: {FocusMs: 1000}, ids[1]: {FocusMs: 4000, Revisits: 1}},
+		{ids[0]: {FocusMs: 3000}, ids[1]: {FocusMs: 6000}},
+	}}
+	svc := NewAnalyticsQueryService(repos.forms, repos.questions, repos.collaborators, repos.orgs, nil, 50, timings, memoryDraftRepository{drafts}, TimingConfig{SampleLimit: 100, AbandonAfter: 24 * time.Hour})
+
+	report, err := svc.QuestionTimings(ctx, form.ID, owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Responses != 8 || report.Timed != 2 || report.AbandonedDrafts != 2 || timings.limit != 100 {
+		t.Errorf("report of %d responses, %d timed, %d abandoned drafts (limit %d)", report.Responses, report.Timed, report.AbandonedDrafts, timings.limit)
+	}
+	if len(report.Questions) != 3 || report.Questions[0].Title != "Name" || report.Questions[2].Title != "Comment" {
+		t.Fatalf("questions = %+v, want the questions in form order", report.Questions)
+	}
+	name, plan := report.Questions[0], report.Questions[1]
+	if name.MedianMs != 2000 || plan.MedianMs != 5000 || plan.MeanRevisits != 0.5 {
+		t.Errorf("timings of name = %+v, plan = %+v", name, plan)
+	}
+	// One draft stopped after the name, its plan left empty, one after the plan
+	if name.DroppedOff != 1 || name.Reached != 10 || plan.DroppedOff != 1 || plan.Reached != 9 || report.Questions[2].Reached != 8 {
+		t.Errorf("drop-off of name = %+v, plan = %+v", name, plan)
+	}
+
+	if _, err := svc.QuestionTimings(ctx, form.ID, uuid.New()); !errors.Is(err, ErrNotFormOwner) {
+		t.Errorf("not the owner: err = %v, want ErrNotFormOwner", err)
+	}
+	unconfigured := NewAnalyticsQueryService(repos.forms, repos.questions, repos.collaborators, repos.orgs, nil, 50, nil, memoryDraftRepository{drafts}, TimingConfig{})
+	if _, err := unconfigured.QuestionTimings(ctx, form.ID, owner); !errors.Is(err, analytics.ErrNotConfigured) {
+		t.Errorf("without a projection database: err = %v, want ErrNotConfigured", err)
+	}
+}
@@ -0,0 +1,354 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/analytics"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+	"github.com/Mir00r/X-Form-Backend/shared/storage"
+)
+
+const (
+	// cleanupBatchSize is the number of candidate forms read per page
+	cleanupBatchSize = 100
+	// cleanupSampleSize bounds the form IDs a dry run returns
+	cleanupSampleSize = 20
+	// cleanupStaleAfter is how long a running job may go without saving its
+	// progress before another replica takes it over
+	cleanupStaleAfter = 15 * time.Minute
+)
+
+var (
+	// ErrCleanupInvalid is returned for cleanups that can't be started
+	ErrCleanupInvalid = errors.New("invalid cleanup")
+	// ErrCleanupJobFinished is returned when cancelling a job that finished
+	ErrCleanupJobFinished = errors.New("cleanup job already finished")
+)
+
+// CleanupService defines the interface for the admin cleanup of abandoned
+// forms. A cleanup archives the forms matching its criteria: the definition
+// of each form is exported to storage, then the form is soft deleted and
+// form.archived is published. Cleanups run as jobs, claimed by a single
+// replica and walking the candidates page by page.
+type CleanupService interface {
+	// Preview counts the forms a cleanup would archive, with a sample of
+	// their IDs, archiving nothing
+	Preview(ctx context.Context, criteria models.CleanupCriteria) (*CleanupPreview, error)
+	// Start queues a cleanup job
+	Start(ctx context.Context, criteria models.CleanupCriteria, requestedBy string) (*models.CleanupJob, error)
+	GetJob(ctx context.Context, id uuid.UUID) (*models.CleanupJob, error)
+	// CancelJob stops a job after the page it is archiving. Forms archived
+	// before stay archived.
+	CancelJob(ctx context.Context, id uuid.UUID) (*models.CleanupJob, error)
+	// RunJobs runs the queued jobs, checking for them every interval until
+	// ctx is cancelled
+	RunJobs(ctx context.Context, interval time.Duration)
+}
+
+// CleanupRequest starts a cleanup, or previews it with DryRun
+type CleanupRequest struct {
+	models.CleanupCriteria
+	DryRun bool `json:"dry_run"`
+	// RequestedBy is the admin starting the cleanup, set by the gateway
+	RequestedBy string `json:"requested_by"`
+}
+
+// CleanupPreview is what a cleanup would archive
+type CleanupPreview struct {
+	Matched int         `json:"matched"`
+	Sample  []uuid.UUID `json:"sample" swaggertype:"array,string"`
+}
+
+// formArchive is the JSON document a cleanup exports for each form
+type formArchive struct {
+	archivedForm
+	ArchivedAt   time.Time `json:"archived_at"`
+	CleanupJobID uuid.UUID `json:"cleanup_job_id"`
+}
+
+// cleanupService implements CleanupService interface
+type cleanupService struct {
+	formRepo     repository.FormRepository
+	questionRepo repository.QuestionRepository
+	jobs         repository.CleanupJobRepository
+	aggregator   analytics.Aggregator
+	storage      storage.Backend
+	publisher    events.Publisher
+	auditor      events.Auditor
+	definitions  *DefinitionCache
+	now          func() time.Time
+}
+
+// NewCleanupService creates a new cleanup service instance. Responses are
+// counted by aggregator; without one, cleanups can't select forms by their
+// responses. The forms archived are dropped from definitions, which may be
+// nil.
+func NewCleanupService(formRepo repository.FormRepository, questionRepo repository.QuestionRepository, jobs repository.CleanupJobRepository, aggregator analytics.Aggregator, store storage.Backend, publisher events.Publisher, auditor events.Auditor, definitions *DefinitionCache) CleanupService {
+	return &cleanupService{
+		formRepo:     formRepo,
+		questionRepo: questionRepo,
+		jobs:         jobs,
+		aggregator:   aggregator,
+		storage:      store,
+		publisher:    publisher,
+		auditor:      auditor,
+		definitions:  definitions,
+		now:          time.Now,
+	}
+}
+
+// Preview walks every candidate, as the job would, keeping the first IDs
+func (s *cleanupService) Preview(ctx context.Context, criteria models.CleanupCriteria) (*CleanupPreview, error) {
+	if err := s.validate(criteria); err != nil {
+		return nil, err
+	}
+
+	preview := &CleanupPreview{Sample: []uuid.UUID{}}
+	var after repository.CleanupCursor
+	for {
+		forms, err := s.formRepo.ListCleanupCandidates(ctx, criteria, after, cleanupBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list cleanup candidates: %w", err)
+		}
+		for _, form := range forms {
+			match, err := s.matches(ctx, criteria, form)
+			if err != nil {
+				return nil, err
+			}
+			if !match {
+				continue
+			}
+			preview.Matched++
+			if len(preview.Sample) < cleanupSampleSize {
+				preview.Sample = append(preview.Sample, form.ID)
+			}
+		}
+		if len(forms) < cleanupBatchSize {
+			return preview, nil
+		}
+		last := forms[len(forms)-1]
+		after = repository.CleanupCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+}
+
+// Start queues the job; a replica running jobs claims it on its next check
+func (s *cleanupService) Start(ctx context.Context, criteria models.CleanupCriteria, requestedBy string) (*models.CleanupJob, error) {
+	if err := s.validate(criteria); err != nil {
+		return nil, err
+	}
+
+	job := &models.CleanupJob{
+		Status:      models.CleanupJobQueued,
+		Criteria:    datatypes.NewJSONType(criteria),
+		RequestedBy: requestedBy,
+	}
+	if err := s.jobs.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to create cleanup job: %w", err)
+	}
+	return job, nil
+}
+
+// GetJob returns the job with its progress
+func (s *cleanupService) GetJob(ctx context.Context, id uuid.UUID) (*models.CleanupJob, error) {
+	return s.jobs.Get(ctx, id)
+}
+
+// CancelJob cancels a queued or running job. The replica running it stops
+// once it saves the progress of its page.
+func (s *cleanupService) CancelJob(ctx context.Context, id uuid.UUID) (*models.CleanupJob, error) {
+	cancelled, err := s.jobs.Cancel(ctx, id, s.now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel cleanup job: %w", err)
+	}
+	job, err := s.jobs.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !cancelled {
+		return job, ErrCleanupJobFinished
+	}
+	return job, nil
+}
+
+// RunJobs claims jobs one at a time and runs each to its end
+func (s *cleanupService) RunJobs(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for ctx.Err() == nil {
+				now := s.now().UTC()
+				job, err := s.jobs.Claim(ctx, now, now.Add(-cleanupStaleAfter))
+				if err != nil {
+					log.Printf("Failed to claim cleanup job: %v", err)
+					break
+				}
+				if job == nil {
+					break
+				}
+				s.runJob(ctx, job)
+			}
+		}
+	}
+}
+
+// runJob archives the matching forms of a claimed job from its cursor on,
+// saving its progress after each page. A job whose progress can't be saved
+// is left running, and taken over once it went stale.
+func (s *cleanupService) runJob(ctx context.Context, job *models.CleanupJob) {
+	criteria := job.Criteria.Data()
+	var after repository.CleanupCursor
+	if job.CursorCreatedAt != nil && job.CursorID != nil {
+		after = repository.CleanupCursor{CreatedAt: *job.CursorCreatedAt, ID: *job.CursorID}
+	}
+	log.Printf("Running cleanup job %s requested by %s", job.ID, job.RequestedBy)
+
+	for {
+		forms, err := s.formRepo.ListCleanupCandidates(ctx, criteria, after, cleanupBatchSize)
+		if err != nil {
+			s.finish(ctx, job, models.CleanupJobFailed, fmt.Sprintf("failed to list cleanup candidates: %v", err))
+			return
+		}
+
+		for _, form := range forms {
+			match, err := s.matches(ctx, criteria, form)
+			if err != nil {
+				log.Printf("Cleanup job %s: failed to check form %s: %v", job.ID, form.ID, err)
+				job.Failed++
+				continue
+			}
+			if !match {
+				continue
+			}
+			job.Matched++
+			if err := s.archive(ctx, job, form); err != nil {
+				log.Printf("Cleanup job %s: failed to archive form %s: %v", job.ID, form.ID, err)
+				job.Failed++
+				continue
+			}
+			job.Archived++
+		}
+
+		if len(forms) > 0 {
+			last := forms[len(forms)-1]
+			after = repository.CleanupCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+			job.CursorCreatedAt, job.CursorID = &last.CreatedAt, &last.ID
+		}
+		running, err := s.jobs.SaveProgress(ctx, job)
+		if err != nil {
+			log.Printf("Cleanup job %s: failed to save progress: %v", job.ID, err)
+			return
+		}
+		if !running {
+			log.Printf("Cleanup job %s cancelled after archiving %d forms", job.ID, job.Archived)
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if len(forms) < cleanupBatchSize {
+			s.finish(ctx, job, models.CleanupJobCompleted, "")
+			return
+		}
+	}
+}
+
+// finish records the final status of a job
+func (s *cleanupService) finish(ctx context.Context, job *models.CleanupJob, status models.CleanupJobStatus, message string) {
+	finishedAt := s.now().UTC()
+	job.Status = status
+	job.Error = message
+	job.FinishedAt = &finishedAt
+	if err := s.jobs.Finish(ctx, job); err != nil {
+		log.Printf("Cleanup job %s: failed to record its end: %v", job.ID, err)
+		return
+	}
+	log.Printf("Cleanup job %s %s: %d matched, %d archived, %d failed", job.ID, status, job.Matched, job.Archived, job.Failed)
+}
+
+// archive exports the definition of the form to archives/forms/{id}.json,
+// then deletes the form. A form whose export fails is kept.
+func (s *cleanupService) archive(ctx context.Context, job *models.CleanupJob, form *models.Form) error {
+	questions, err := s.questionRepo.GetByFormID(ctx, form.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get questions: %w", err)
+	}
+	if questions == nil {
+		questions = []*models.Question{}
+	}
+
+	body, err := json.MarshalIndent(formArchive{
+		archivedForm: archivedForm{Form: form, Questions: questions},
+		ArchivedAt:   s.now().UTC(),
+		CleanupJobID: job.ID,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode archive: %w", err)
+	}
+	key := fmt.Sprintf("archives/forms/%s.json", form.ID)
+	if err := s.storage.Put(ctx, key, "application/json", body); err != nil {
+		return fmt.Errorf("failed to store archive: %w", err)
+	}
+
+	if err := s.formRepo.Delete(ctx, form.ID); err != nil {
+		return fmt.Errorf("failed to delete form: %w", err)
+	}
+	s.definitions.invalidate(ctx, form.ID)
+
+	err = s.publisher.Publish(ctx, events.FormArchived, form.ID.String(), map[string]interface{}{
+		"form_id":         form.ID.String(),
+		"organization_id": form.OrganizationID.String(),
+		"owner_id":        form.UserID.String(),
+		"cleanup_job_id":  job.ID.String(),
+		"archive_key":     key,
+	})
+	if err != nil {
+		log.Printf("Failed to publish %s event for form %s: %v", events.FormArchived, form.ID, err)
+	}
+	s.auditor.Record(ctx, events.AuditEvent{
+		Action:       events.AuditFormArchived,
+		Actor:        job.RequestedBy,
+		ResourceType: "form",
+		ResourceID:   form.ID.String(),
+		Before:       formSummary(form),
+		After:        map[string]interface{}{"cleanup_job_id": job.ID.String(), "archive_key": key},
+	})
+	return nil
+}
+
+// matches checks the criteria the database can't: whether the form ever
+// received a response
+func (s *cleanupService) matches(ctx context.Context, criteria models.CleanupCriteria, form *models.Form) (bool, error) {
+	if !criteria.ZeroResponses {
+		return true, nil
+	}
+	summary, err := s.aggregator.Summary(ctx, form.ID.String(), form.CreatedAt, s.now().UTC())
+	if err != nil {
+		return false, err
+	}
+	return summary.TotalResponses == 0, nil
+}
+
+func (s *cleanupService) validate(criteria models.CleanupCriteria) error {
+	if err := criteria.Validate(s.now()); err != nil {
+		return fmt.Errorf("%w: %v", ErrCleanupInvalid, err)
+	}
+	if criteria.ZeroResponses && s.aggregator == nil {
+		return fmt.Errorf("%w: zero_responses needs the analytics service, which is not configured", ErrCleanupInvalid)
+	}
+	return nil
+}
@@ -0,0 +1,328 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+	"github.com/Mir00r/X-Form-Backend/shared/storage"
+)
+
+// memoryCleanupJobRepository keeps cleanup jobs in memory. onSave is called
+// before the progress of a job is saved.
+type memoryCleanupJobRepository struct {
+	mu     sync.Mutex
+	jobs   map[uuid.UUID]models.CleanupJob
+	onSave func(job *models.CleanupJob)
+}
+
+func newMemoryCleanupJobRepository() *memoryCleanupJobRepository {
+	return &memoryCleanupJobRepository{jobs: make(map[uuid.UUID]models.CleanupJob)}
+}
+
+func (r *memoryCleanupJobRepository) Create(_ context.Context, job *models.CleanupJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job.ID = uuid.New()
+	r.jobs[job.ID] = *job
+	return nil
+}
+
+func (r *memoryCleanupJobRepository) Get(_ context.Context, id uuid.UUID) (*models.CleanupJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, repository.ErrCleanupJobNotFound
+	}
+	return &job, nil
+}
+
+func (r *memoryCleanupJobRepository) Claim(_ context.Context, now, staleBefore time.Time) (*models.CleanupJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, job := range r.jobs {
+		if job.Status == models.CleanupJobQueued || (job.Status == models.CleanupJobRunning && job.UpdatedAt.Before(staleBefore)) {
+			job.Status, job.StartedAt, job.UpdatedAt = models.CleanupJobRunning, &now, now
+			r.jobs[id] = job
+			return &job, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryCleanupJobRepository) SaveProgress(_ context.Context, job *models.CleanupJob) (bool, error) {
+	if r.onSave != nil {
+		r.onSave(job)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := r.jobs[job.ID]
+	stored.Matched, stored.Archived, stored.Failed = job.Matched, job.Archived, job.Failed
+	stored.CursorCreatedAt, stored.CursorID = job.CursorCreatedAt, job.CursorID
+	r.jobs[job.ID] = stored
+	return stored.Status == models.CleanupJobRunning, nil
+}
+
+func (r *memoryCleanupJobRepository) Finish(_ context.Context, job *models.CleanupJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.jobs[job.ID].Status == models.CleanupJobRunning {
+		r.jobs[job.ID] = *job
+	}
+	return nil
+}
+
+func (r *memoryCleanupJobRepository) Cancel(_ context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok || job.Status.Finished() {
+		return false, nil
+	}
+	job.Status, job.FinishedAt = models.CleanupJobCancelled, &now
+	r.jobs[id] = job
+	return true, nil
+}
+
+type cleanupFixture struct {
+	*memoryStore
+	svc        *cleanupService
+	jobs       *memoryCleanupJobRepository
+	aggregator *fakeAggregator
+	store      storage.Backend
+	publisher  *recordingPublisher
+	owner      uuid.UUID
+}
+
+// newCleanupFixture creates forms abandoned in 2023 and forms in use in 2024
+func newCleanupFixture(t *testing.T, abandoned, inUse int) *cleanupFixture {
+	t.Helper()
+	store, err := storage.NewLocalStorage(t.TempDir(), "http://localhost:8001", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &cleanupFixture{
+		memoryStore: newMemoryStore(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)),
+		jobs:        newMemoryCleanupJobRepository(),
+		aggregator:  &fakeAggregator{responses: make(map[string]int)},
+		store:       store,
+		publisher:   &recordingPublisher{},
+		owner:       uuid.New(),
+	}
+	for i := 0; i < abandoned+inUse; i++ {
+		f.clock.advance(time.Minute)
+		f.createNotifiedForm(t, f.owner, models.NotificationSettings{})
+	}
+	f.clock.t = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	touched := 0
+	for id, form := range f.forms.forms {
+		if touched == inUse {
+			break
+		}
+		form.UpdatedAt = f.clock.now()
+		f.forms.forms[id] = form
+		touched++
+	}
+
+	f.svc = NewCleanupService(f.forms, f.questions, f.jobs, f.aggregator, store, f.publisher, events.LogAuditor{}, nil).(*cleanupService)
+	f.svc.now = f.clock.now
+	return f
+}
+
+func (f *cleanupFixture) run(t *testing.T) {
+	t.Helper()
+	job, err := f.jobs.Claim(context.Background(), f.clock.now(), f.clock.now().Add(-cleanupStaleAfter))
+	if err != nil || job == nil {
+		t.Fatalf("Claim = %v, %v; want the queued job", job, err)
+	}
+	f.svc.runJob(context.Background(), job)
+}
+
+func inactiveBefore(t time.Time) models.CleanupCriteria {
+	return models.CleanupCriteria{InactiveBefore: &t}
+}
+
+func TestCleanupDryRunArchivesNothing(t *testing.T) {
+	ctx := context.Background()
+	f := newCleanupFixture(t, 230, 20)
+
+	preview, err := f.svc.Preview(ctx, inactiveBefore(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Matched != 230 || len(preview.Sample) != cleanupSampleSize {
+		t.Errorf("preview = %d matched with %d sampled, want 230 with %d", preview.Matched, len(preview.Sample), cleanupSampleSize)
+	}
+	if len(f.publisher.events) != 0 {
+		t.Errorf("a dry run published %v", f.publisher.events)
+	}
+	for _, id := range preview.Sample {
+		if _, err := f.forms.GetByID(ctx, id); err != nil {
+			t.Errorf("form %s sampled by a dry run is gone: %v", id, err)
+		}
+	}
+}
+
+func TestCleanupArchivesMatchingForms(t *testing.T) {
+	ctx := context.Background()
+	f := newCleanupFixture(t, 230, 20)
+
+	// Forms with responses are kept when cleaning up forms without any
+	criteria := inactiveBefore(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	criteria.ZeroResponses = true
+	answered := 0
+	for id, form := range f.forms.forms {
+		if answered == 5 {
+			break
+		}
+		if form.UpdatedAt.Before(*criteria.InactiveBefore) {
+			f.aggregator.responses[id.String()] = 3
+			answered++
+		}
+	}
+
+	preview, err := f.svc.Preview(ctx, criteria)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, err := f.svc.Start(ctx, criteria, "admin-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != models.CleanupJobQueued {
+		t.Errorf("started job status = %s, want queued", job.Status)
+	}
+	f.run(t)
+
+	job, err = f.svc.GetJob(ctx, job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != models.CleanupJobCompleted || job.Matched != 225 || job.Archived != 225 || job.Failed != 0 || job.FinishedAt == nil {
+		t.Errorf("job = %s with %d matched, %d archived and %d failed, want completed with 225 archived",
+			job.Status, job.Matched, job.Archived, job.Failed)
+	}
+	if preview.Matched != job.Matched {
+		t.Errorf("dry run matched %d, the job %d", preview.Matched, job.Matched)
+	}
+
+	remaining := 0
+	for id, form := range f.forms.forms {
+		if form.DeletedAt.Valid {
+			continue
+		}
+		remaining++
+		if f.aggregator.responses[id.String()] == 0 && form.UpdatedAt.Before(*criteria.InactiveBefore) {
+			t.Errorf("form %s matches but was kept", id)
+		}
+	}
+	if remaining != 25 {
+		t.Errorf("%d forms remain, want the 20 in use and 5 answered", remaining)
+	}
+
+	if len(f.publisher.events) != 225 || f.publisher.events[0] != events.FormArchived {
+		t.Fatalf("published %d events, want %d %s", len(f.publisher.events), 225, events.FormArchived)
+	}
+	archived := f.publisher.data[0]
+	if archived["cleanup_job_id"] != job.ID.String() {
+		t.Errorf("event = %v, want the job ID", archived)
+	}
+
+	// The definition of each form is exported before it is deleted
+	body, err := f.store.Open(ctx, archived["archive_key"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var archive struct {
+		ID           string             `json:"id"`
+		Title        string             `json:"title"`
+		Questions    []*models.Question `json:"questions"`
+		CleanupJobID string             `json:"cleanup_job_id"`
+	}
+	if err := json.Unmarshal(data, &archive); err != nil {
+		t.Fatal(err)
+	}
+	if archive.ID != archived["form_id"] || archive.Title != "Feedback" || archive.Questions == nil || archive.CleanupJobID != job.ID.String() {
+		t.Errorf("archive = %s", data)
+	}
+}
+
+func TestCleanupCancellation(t *testing.T) {
+	ctx := context.Background()
+	f := newCleanupFixture(t, 250, 0)
+	criteria := inactiveBefore(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	// A job cancelled while it archives its first page stops after it
+	job, err := f.svc.Start(ctx, criteria, "admin-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.jobs.onSave = func(*models.CleanupJob) {
+		f.jobs.onSave = nil
+		if _, err := f.svc.CancelJob(ctx, job.ID); err != nil {
+			t.Errorf("CancelJob: %v", err)
+		}
+	}
+	f.run(t)
+
+	job, err = f.svc.GetJob(ctx, job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != models.CleanupJobCancelled || job.Archived != cleanupBatchSize || len(f.publisher.events) != cleanupBatchSize {
+		t.Errorf("cancelled job = %s with %d archived and %d events, want cancelled after %d",
+			job.Status, job.Archived, len(f.publisher.events), cleanupBatchSize)
+	}
+
+	if _, err := f.svc.CancelJob(ctx, job.ID); !errors.Is(err, ErrCleanupJobFinished) {
+		t.Errorf("cancelling again: err = %v, want ErrCleanupJobFinished", err)
+	}
+	if _, err := f.svc.CancelJob(ctx, uuid.New()); !errors.Is(err, repository.ErrCleanupJobNotFound) {
+		t.Errorf("cancelling an unknown job: err = %v, want ErrCleanupJobNotFound", err)
+	}
+}
+
+func TestCleanupRejectsCriteria(t *testing.T) {
+	ctx := context.Background()
+	f := newCleanupFixture(t, 1, 0)
+	future := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	orgID := uuid.New()
+
+	tests := map[string]models.CleanupCriteria{
+		"no activity criterion": {OrganizationID: &orgID, Status: models.FormStatusDraft},
+		"future cutoff":         {InactiveBefore: &future},
+		"unknown status":        {ZeroResponses: true, Status: "archived"},
+	}
+	for name, criteria := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := f.svc.Start(ctx, criteria, "admin-1"); !errors.Is(err, ErrCleanupInvalid) {
+				t.Errorf("Start: err = %v, want ErrCleanupInvalid", err)
+			}
+		})
+	}
+
+	// Without the analytics service, responses can't be counted
+	f.svc.aggregator = nil
+	if _, err := f.svc.Preview(ctx, models.CleanupCriteria{ZeroResponses: true}); !errors.Is(err, ErrCleanupInvalid) {
+		t.Errorf("Preview without analytics: err = %v, want ErrCleanupInvalid", err)
+	}
+	if len(f.jobs.jobs) != 0 {
+		t.Errorf("%d jobs created for rejected cleanups", len(f.jobs.jobs))
+	}
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/access"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+)
+
+var (
+	// ErrInsufficientRole is returned when the role of a collaborator does
+	// not allow what they attempted
+	ErrInsufficientRole = errors.New("access denied: your role on this form does not allow this")
+	// ErrCollaboratorNotFound is returned when the collaborator is not on the form
+	ErrCollaboratorNotFound = errors.New("collaborator not found")
+	// ErrCollaboratorExists is returned when the user already collaborates on the form
+	ErrCollaboratorExists = errors.New("user already collaborates on this form")
+	// ErrInvalidCollaborator is returned for collaborators that can't be granted
+	ErrInvalidCollaborator = errors.New("invalid collaborator")
+)
+
+// CollaboratorService defines the interface for the collaborators of a form.
+// Collaborators are listed by anyone with access to the form and managed by
+// its owner and managers.
+type CollaboratorService interface {
+	InviteCollaborator(ctx context.Context, formID, userID uuid.UUID, req InviteCollaboratorRequest) (*models.Collaborator, error)
+	ListCollaborators(ctx context.Context, formID, userID uuid.UUID) ([]*models.Collaborator, error)
+	UpdateCollaborator(ctx context.Context, formID, collaboratorID, userID uuid.UUID, req UpdateCollaboratorRequest) (*models.Collaborator, error)
+	RemoveCollaborator(ctx context.Context, formID, collaboratorID, userID uuid.UUID) error
+}
+
+// InviteCollaboratorRequest grants a user a role on a form
+type InviteCollaboratorRequest struct {
+	UserID uuid.UUID               `json:"user_id" binding:"required" example:"5f0c6a3e-8a44-4c1f-9d43-2b6f3f3f8c11"`
+	Email  string                  `json:"email" binding:"required,email" example:"ada@example.com"`
+	Role   models.CollaboratorRole `json:"role" binding:"required" enums:"viewer,editor,manager" example:"editor"`
+}
+
+// UpdateCollaboratorRequest changes the role of a collaborator
+type UpdateCollaboratorRequest struct {
+	Role models.CollaboratorRole `json:"role" binding:"required" enums:"viewer,editor,manager" example:"viewer"`
+}
+
+// collaboratorService implements CollaboratorService interface
+type collaboratorService struct {
+	collaboratorRepo repository.CollaboratorRepository
+	guard            formGuard
+	auditor          events.Auditor
+	now              func() time.Time
+}
+
+// NewCollaboratorService creates a new collaborator service instance.
+// Collaborator changes are recorded to auditor.
+func NewCollaboratorService(formRepo repository.FormRepository, collaboratorRepo repository.CollaboratorRepository, orgRepo repository.OrganizationRepository, auditor events.Auditor) CollaboratorService {
+	return &collaboratorService{
+		collaboratorRepo: collaboratorRepo,
+		guard:            formGuard{forms: formRepo, collaborators: collaboratorRepo, orgs: organizationScope{orgs: orgRepo}},
+		auditor:          auditor,
+		now:              time.Now,
+	}
+}
+
+// InviteCollaborator grants a user a role on the form. The invited user
+// joins right away, identified by the user ID of their token.
+func (s *collaboratorService) InviteCollaborator(ctx context.Context, formID, userID uuid.UUID, req InviteCollaboratorRequest) (*models.Collaborator, error) {
+	form, err := s.guard.authorize(ctx, formID, userID, access.ManageCollaborators)
+	if err != nil {
+		return nil, err
+	}
+	if req.UserID == form.UserID {
+		return nil, fmt.Errorf("%w: the owner can't be a collaborator", ErrInvalidCollaborator)
+	}
+
+	_, err = s.collaboratorRepo.FindByFormAndUser(ctx, formID, req.UserID)
+	if err == nil {
+		return nil, ErrCollaboratorExists
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get collaborator: %w", err)
+	}
+
+	now := s.now()
+	invitee := req.UserID
+	collaborator := &models.Collaborator{
+		FormID:    formID,
+		UserID:    &invitee,
+		Email:     req.Email,
+		Role:      req.Role,
+		Status:    models.CollaboratorStatusActive,
+		InvitedBy: userID,
+		InvitedAt: now,
+		JoinedAt:  &now,
+	}
+	if err := collaborator.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCollaborator, err)
+	}
+
+	if err := s.collaboratorRepo.Create(ctx, collaborator); err != nil {
+		return nil, fmt.Errorf("failed to create collaborator: %w", err)
+	}
+	s.record(ctx, events.AuditCollaboratorAdded, formID, userID, nil, collaborator)
+	return collaborator, nil
+}
+
+// ListCollaborators lists the collaborators of the form
+func (s *collaboratorService) ListCollaborators(ctx context.Context, formID, userID uuid.UUID) ([]*models.Collaborator, error) {
+	if _, err := s.guard.authorize(ctx, formID, userID, access.View); err != nil {
+		return nil, err
+	}
+
+	collaborators, err := s.collaboratorRepo.GetByFormID(ctx, formID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collaborators: %w", err)
+	}
+	return collaborators, nil
+}
+
+// UpdateCollaborator changes the role of a collaborator
+func (s *collaboratorService) UpdateCollaborator(ctx context.Context, formID, collaboratorID, userID uuid.UUID, req UpdateCollaboratorRequest) (*models.Collaborator, error) {
+	if !req.Role.IsValid() {
+		return nil, fmt.Errorf("%w: invalid role: %s", ErrInvalidCollaborator, req.Role)
+	}
+
+	collaborator, err := s.getCollaborator(ctx, formID, collaboratorID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *collaborator
+	collaborator.Role = req.Role
+	if err := s.collaboratorRepo.Update(ctx, collaborator); err != nil {
+		return nil, fmt.Errorf("failed to update collaborator: %w", err)
+	}
+	s.record(ctx, events.AuditCollaboratorUpdated, formID, userID, &before, collaborator)
+	return collaborator, nil
+}
+
+// RemoveCollaborator revokes the access of a collaborator
+func (s *collaboratorService) RemoveCollaborator(ctx context.Context, formID, collaboratorID, userID uuid.UUID) error {
+	collaborator, err := s.getCollaborator(ctx, formID, collaboratorID, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.collaboratorRepo.Delete(ctx, collaboratorID); err != nil {
+		return fmt.Errorf("failed to remove collaborator: %w", err)
+	}
+	s.record(ctx, events.AuditCollaboratorRemoved, formID, userID, collaborator, nil)
+	return nil
+}
+
+// record records a change of the permissions on a form. The form is the
+// resource; the summaries hold the collaborator and their role, not their
+// email.
+func (s *collaboratorService) record(ctx context.Context, action string, formID, actor uuid.UUID, before, after *models.Collaborator) {
+	summary := func(collaborator *models.Collaborator) interface{} {
+		if collaborator == nil {
+			return nil
+		}
+		return map[string]interface{}{
+			"collaborator_id": collaborator.ID.String(),
+			"user_id":         collaborator.UserID,
+			"role":            collaborator.Role,
+		}
+	}
+	s.auditor.Record(ctx, events.AuditEvent{
+		Action:       action,
+		Actor:        actor.String(),
+		ResourceType: "form",
+		ResourceID:   formID.String(),
+		Before:       summary(before),
+		After:        summary(after),
+	})
+}
+
+// getCollaborator gets a collaborator of the form for a user allowed to
+// manage them
+func (s *collaboratorService) getCollaborator(ctx context.Context, formID, collaboratorID, userID uuid.UUID) (*models.Collaborator, error) {
+	if _, err := s.guard.authorize(ctx, formID, userID, access.ManageCollaborators); err != nil {
+		return nil, err
+	}
+
+	collaborator, err := s.collaboratorRepo.GetByID(ctx, collaboratorID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCollaboratorNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collaborator: %w", err)
+	}
+	if collaborator.FormID != formID {
+		return nil, ErrCollaboratorNotFound
+	}
+	return collaborator, nil
+}
+
+// formGuard authorizes what users do with forms. The owner may do anything,
+// active collaborators what their role allows. Requests acting in an
+// organization only find its forms.
+type formGuard struct {
+	forms         repository.FormRepository
+	collaborators repository.CollaboratorRepository
+	orgs          organizationScope
+}
+
+// authorize gets the form if the user may take action on it. Forms of other
+// organizations than the one the request acts in get ErrFormNotFound, users
+// without access ErrNotFormOwner, and collaborators whose role does not
+// allow the action ErrInsufficientRole.
+func (g formGuard) authorize(ctx context.Context, formID, userID uuid.UUID, action access.Action) (*models.Form, error) {
+	form, err := g.getForm(ctx, formID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if form.UserID == userID {
+		return form, nil
+	}
+	if g.collaborators == nil {
+		return nil, ErrNotFormOwner
+	}
+
+	collaborator, err := g.collaborators.FindByFormAndUser(ctx, formID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFormOwner
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collaborator: %w", err)
+	}
+	if collaborator.Status != models.CollaboratorStatusActive {
+		return nil, ErrNotFormOwner
+	}
+	if !access.Allowed(collaborator.Role, action) {
+		return nil, ErrInsufficientRole
+	}
+	return form, nil
+}
+
+// getForm gets the form in the organization the request acts in, or in any
+// organization for requests acting in none
+func (g formGuard) getForm(ctx context.Context, formID, userID uuid.UUID) (*models.Form, error) {
+	orgID, scoped, err := g.orgs.resolve(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var form *models.Form
+	if scoped {
+		form, err = g.forms.GetInOrganization(ctx, orgID, formID)
+	} else {
+		form, err = g.forms.GetByID(ctx, formID)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrFormNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get form: %w", err)
+	}
+	return form, nil
+}
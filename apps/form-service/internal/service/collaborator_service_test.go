@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+)
+
+type collaboratorFixture struct {
+	*memoryStore
+	formSvc FormService
+	svc     CollaboratorService
+	audit   *recordingAuditor
+	form    *models.Form
+	owner   uuid.UUID
+}
+
+func newCollaboratorFixture(t *testing.T) *collaboratorFixture {
+	t.Helper()
+	f := &collaboratorFixture{memoryStore: newMemoryStore(time.Now()), audit: &recordingAuditor{}, owner: uuid.New()}
+	f.form = f.createForm(t, &models.Form{UserID: f.owner, Title: "Team survey", Status: models.FormStatusDraft})
+	f.formSvc = NewFormService(f.forms, nil, f.collaborators, f.orgs, events.LogPublisher{}, f.audit, nil, nil, nil, nil)
+	f.svc = NewCollaboratorService(f.forms, f.collaborators, f.orgs, f.audit)
+	return f
+}
+
+func (f *collaboratorFixture) invite(t *testing.T, role models.CollaboratorRole) (uuid.UUID, *models.Collaborator) {
+	t.Helper()
+	userID := uuid.New()
+	collaborator, err := f.svc.InviteCollaborator(context.Background(), f.form.ID, f.owner, InviteCollaboratorRequest{
+		UserID: userID, Email: "collaborator@example.com", Role: role,
+	})
+	if err != nil {
+		t.Fatalf("InviteCollaborator(%s) error: %v", role, err)
+	}
+	return userID, collaborator
+}
+
+func TestCollaboratorRolesGateFormActions(t *testing.T) {
+	f := newCollaboratorFixture(t)
+	ctx := context.Background()
+	viewer, _ := f.invite(t, models.CollaboratorRoleViewer)
+	editor, _ := f.invite(t, models.CollaboratorRoleEditor)
+	manager, _ := f.invite(t, models.CollaboratorRoleManager)
+	stranger := uuid.New()
+
+	title := "Renamed"
+	update := func(userID uuid.UUID) error {
+		_, err := f.formSvc.UpdateForm(ctx, f.form.ID, userID, UpdateFormRequest{Title: &title})
+		return err
+	}
+	publish := func(userID uuid.UUID) error {
+		_, _, err := f.formSvc.PublishForm(ctx, f.form.ID, userID)
+		return err
+	}
+	invite := func(userID uuid.UUID) error {
+		_, err := f.svc.InviteCollaborator(ctx, f.form.ID, userID, InviteCollaboratorRequest{
+			UserID: uuid.New(), Email: "new@example.com", Role: models.CollaboratorRoleViewer,
+		})
+		return err
+	}
+
+	tests := []struct {
+		name   string
+		userID uuid.UUID
+		do     func(uuid.UUID) error
+		want   error
+	}{
+		{"viewer reads", viewer, func(id uuid.UUID) error { _, err := f.formSvc.GetForm(ctx, f.form.ID, id); return err }, nil},
+		{"viewer lists collaborators", viewer, func(id uuid.UUID) error { _, err := f.svc.ListCollaborators(ctx, f.form.ID, id); return err }, nil},
+		{"viewer can't edit", viewer, update, ErrInsufficientRole},
+		{"editor edits", editor, update, nil},
+		{"editor can't publish", editor, publish, ErrInsufficientRole},
+		{"editor can't invite", editor, invite, ErrInsufficientRole},
+		{"manager invites", manager, invite, nil},
+		{"stranger can't read", stranger, func(id uuid.UUID) error { _, err := f.formSvc.GetForm(ctx, f.form.ID, id); return err }, ErrNotFormOwner},
+		{"stranger can't list collaborators", stranger, func(id uuid.UUID) error { _, err := f.svc.ListCollaborators(ctx, f.form.ID, id); return err }, ErrNotFormOwner},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.do(tt.userID)
+			if tt.want == nil && err != nil {
+				t.Errorf("err = %v, want none", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if err := f.formSvc.DeleteForm(ctx, f.form.ID, manager); err != nil {
+		t.Errorf("manager DeleteForm() error: %v", err)
+	}
+}
+
+func TestInviteCollaboratorRejections(t *testing.T) {
+	f := newCollaboratorFixture(t)
+	ctx := context.Background()
+	editor, _ := f.invite(t, models.CollaboratorRoleEditor)
+
+	tests := []struct {
+		name string
+		req  InviteCollaboratorRequest
+		want error
+	}{
+		{"owner", InviteCollaboratorRequest{UserID: f.owner, Email: "owner@example.com", Role: models.CollaboratorRoleEditor}, ErrInvalidCollaborator},
+		{"owner role", InviteCollaboratorRequest{UserID: uuid.New(), Email: "a@example.com", Role: models.CollaboratorRoleOwner}, ErrInvalidCollaborator},
+		{"unknown role", InviteCollaboratorRequest{UserID: uuid.New(), Email: "a@example.com", Role: "admin"}, ErrInvalidCollaborator},
+		{"existing collaborator", InviteCollaboratorRequest{UserID: editor, Email: "a@example.com", Role: models.CollaboratorRoleViewer}, ErrCollaboratorExists},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := f.svc.InviteCollaborator(ctx, f.form.ID, f.owner, tt.req); !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateAndRemoveCollaborator(t *testing.T) {
+	f := newCollaboratorFixture(t)
+	ctx := context.Background()
+	editor, collaborator := f.invite(t, models.CollaboratorRoleEditor)
+
+	updated, err := f.svc.UpdateCollaborator(ctx, f.form.ID, collaborator.ID, f.owner, UpdateCollaboratorRequest{Role: models.CollaboratorRoleViewer})
+	if err != nil || updated.Role != models.CollaboratorRoleViewer {
+		t.Fatalf("UpdateCollaborator() = %v, %v", updated, err)
+	}
+	title := "Renamed"
+	if _, err := f.formSvc.UpdateForm(ctx, f.form.ID, editor, UpdateFormRequest{Title: &title}); !errors.Is(err, ErrInsufficientRole) {
+		t.Errorf("demoted editor UpdateForm() err = %v, want ErrInsufficientRole", err)
+	}
+
+	// A collaborator of another form is not found on this one
+	other := &models.Form{UserID: f.owner, Title: "Other survey", Status: models.FormStatusDraft}
+	f.forms.Create(ctx, other)
+	if err := f.svc.RemoveCollaborator(ctx, other.ID, collaborator.ID, f.owner); !errors.Is(err, ErrCollaboratorNotFound) {
+		t.Errorf("RemoveCollaborator() on another form err = %v, want ErrCollaboratorNotFound", err)
+	}
+
+	if err := f.svc.RemoveCollaborator(ctx, f.form.ID, collaborator.ID, f.owner); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.formSvc.GetForm(ctx, f.form.ID, editor); !errors.Is(err, ErrNotFormOwner) {
+		t.Errorf("removed collaborator GetForm() err = %v, want ErrNotFormOwner", err)
+	}
+}
+
+func TestPermissionChangesAreAudited(t *testing.T) {
+	f := newCollaboratorFixture(t)
+	ctx := context.Background()
+	_, collaborator := f.invite(t, models.CollaboratorRoleEditor)
+
+	if _, err := f.svc.UpdateCollaborator(ctx, f.form.ID, collaborator.ID, f.owner, UpdateCollaboratorRequest{Role: models.CollaboratorRoleManager}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.svc.RemoveCollaborator(ctx, f.form.ID, collaborator.ID, f.owner); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := f.formSvc.PublishForm(ctx, f.form.ID, f.owner); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.formSvc.DeleteForm(ctx, f.form.ID, f.owner); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		events.AuditCollaboratorAdded, events.AuditCollaboratorUpdated, events.AuditCollaboratorRemoved,
+		events.AuditFormPublished, events.AuditFormDeleted,
+	}
+	if got := f.audit.actions(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("audited %v, want %v", got, want)
+	}
+
+	update := f.audit.events[1]
+	if update.Actor != f.owner.String() || update.ResourceID != f.form.ID.String() {
+		t.Errorf("update audited as %+v", update)
+	}
+	before := update.Before.(map[string]interface{})
+	after := update.After.(map[string]interface{})
+	if before["role"] != models.CollaboratorRoleEditor || after["role"] != models.CollaboratorRoleManager {
+		t.Errorf("role change audited as %v -> %v", before["role"], after["role"])
+	}
+	if published := f.audit.events[3]; published.Before.(map[string]interface{})["status"] != models.FormStatusDraft {
+		t.Errorf("publish audited before status %v, want draft", published.Before)
+	}
+}
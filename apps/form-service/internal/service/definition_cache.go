@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/singleflight"
+	"gorm.io/datatypes"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+)
+
+// DefinitionCacheMetrics counts the lookups of the definition cache by
+// result: hit, miss, or error when the cache could not be read and the
+// definition was read from the database
+type DefinitionCacheMetrics struct {
+	Lookups *prometheus.CounterVec
+}
+
+// NewDefinitionCacheMetrics creates and registers the definition cache
+// metrics
+func NewDefinitionCacheMetrics(reg prometheus.Registerer) *DefinitionCacheMetrics {
+	m := &DefinitionCacheMetrics{
+		Lookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "form_service_definition_cache_lookups_total",
+			Help: "Lookups of published form definitions in the cache, by result: hit, miss or error",
+		}, []string{"result"}),
+	}
+	if reg != nil {
+		reg.MustRegister(m.Lookups)
+	}
+	return m
+}
+
+func (m *DefinitionCacheMetrics) observe(result string) {
+	if m == nil {
+		return
+	}
+	m.Lookups.WithLabelValues(result).Inc()
+}
+
+// DefinitionCache serves the definitions of published forms from a cache
+// shared by the replicas, in front of the database. Concurrent misses of a
+// form on a replica share one database load. The cache is optional: when it
+// fails, definitions are read from the database as if it were empty.
+type DefinitionCache struct {
+	store   repository.DefinitionCache
+	ttl     time.Duration
+	metrics *DefinitionCacheMetrics
+	loads   singleflight.Group
+}
+
+// NewDefinitionCache creates a definition cache keeping definitions in
+// store for ttl
+func NewDefinitionCache(store repository.DefinitionCache, ttl time.Duration, metrics *DefinitionCacheMetrics) *DefinitionCache {
+	return &DefinitionCache{store: store, ttl: ttl, metrics: metrics}
+}
+
+// get returns the definition of the form at version, or the current one for
+// version 0, loading and caching it with load on a miss. Without a cache
+// every definition is loaded.
+func (c *DefinitionCache) get(ctx context.Context, formID uuid.UUID, version int, load func(ctx context.Context) (*formDefinition, error)) (*formDefinition, error) {
+	if c == nil {
+		return load(ctx)
+	}
+
+	data, err := c.store.Get(ctx, formID, version)
+	switch {
+	case err == nil:
+		var definition formDefinition
+		if err := json.Unmarshal(data, &definition); err == nil {
+			c.metrics.observe("hit")
+			return &definition, nil
+		}
+		log.Printf("Failed to decode the cached definition of form %s: %v", formID, err)
+		c.metrics.observe("error")
+	case errors.Is(err, repository.ErrDefinitionNotCached):
+		c.metrics.observe("miss")
+	default:
+		log.Printf("Failed to read the cached definition of form %s: %v", formID, err)
+		c.metrics.observe("error")
+	}
+
+	// The load is shared with the requests arriving meanwhile, so it must
+	// outlive the request that started it
+	loaded, err, _ := c.loads.Do(formID.String(), func() (interface{}, error) {
+		definition, err := load(context.WithoutCancel(ctx))
+		if err != nil {
+			return nil, err
+		}
+		c.set(context.WithoutCancel(ctx), definition)
+		return definition, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	definition := loaded.(*formDefinition)
+	// A load for the current version may be shared with a request for
+	// another one
+	if version != 0 && definition.Form.Version != version {
+		return load(ctx)
+	}
+	return definition, nil
+}
+
+// set caches a definition, logging the failures
+func (c *DefinitionCache) set(ctx context.Context, definition *formDefinition) {
+	data, err := json.Marshal(definition)
+	if err == nil {
+		err = c.store.Set(ctx, definition.Form.ID, definition.Form.Version, data, c.ttl)
+	}
+	if err != nil {
+		log.Printf("Failed to cache the definition of form %s: %v", definition.Form.ID, err)
+	}
+}
+
+// invalidate drops the cached definition of a form that changed. A failure
+// is logged; the definition cached then expires with its TTL.
+func (c *DefinitionCache) invalidate(ctx context.Context, formID uuid.UUID) {
+	if c == nil {
+		return
+	}
+	c.loads.Forget(formID.String())
+	if err := c.store.Delete(ctx, formID); err != nil {
+		log.Printf("Failed to invalidate the cached definition of form %s: %v", formID, err)
+	}
+}
+
+// formDefinition is what the cache keeps of a published form and its
+// questions: the fields respondents are served, without the bookkeeping of
+// the models. ETag is computed once when the definition is loaded.
+type formDefinition struct {
+	ETag      string               `json:"etag"`
+	Form      definitionForm       `json:"form"`
+	Questions []definitionQuestion `json:"questions"`
+}
+
+type definitionForm struct {
+	ID                uuid.UUID         `json:"id"`
+	UserID            uuid.UUID         `json:"user_id"`
+	OrganizationID    uuid.UUID         `json:"organization_id"`
+	Slug              *string           `json:"slug,omitempty"`
+	Title             string            `json:"title"`
+	Description       string            `json:"description,omitempty"`
+	Status            models.FormStatus `json:"status"`
+	Settings          datatypes.JSON    `json:"settings,omitempty"`
+	ThrottledAt       *time.Time        `json:"throttled_at,omitempty"`
+	CreatedAt         time.Time         `json:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at"`
+	RetentionDays     *int              `json:"retention_days,omitempty"`
+	DefaultLocale     string            `json:"default_locale"`
+	Translations      datatypes.JSON    `json:"translations,omitempty"`
+	Rules             datatypes.JSON    `json:"rules,omitempty"`
+	Version           int               `json:"version"`
+	CollaboratorCount int               `json:"collaborator_count,omitempty"`
+}
+
+type definitionQuestion struct {
+	ID                uuid.UUID                `json:"id"`
+	Type              models.QuestionType      `json:"type"`
+	Key               string                   `json:"key"`
+	Title             string                   `json:"title"`
+	Description       string                   `json:"description,omitempty"`
+	Order             int                      `json:"order"`
+	Options           datatypes.JSON           `json:"options,omitempty"`
+	Validation        datatypes.JSON           `json:"validation,omitempty"`
+	Display           datatypes.JSON           `json:"display,omitempty"`
+	ResultsVisibility models.ResultsVisibility `json:"results_visibility"`
+	LibraryQuestionID *uuid.UUID               `json:"library_question_id,omitempty"`
+	LibraryVersion    *int                     `json:"library_version,omitempty"`
+	CreatedAt         time.Time                `json:"created_at"`
+	UpdatedAt         time.Time                `json:"updated_at"`
+}
+
+// newFormDefinition compacts a published form and its questions, computing
+// the ETag of the definition
+func newFormDefinition(form *models.Form, questions []*models.Question) (*formDefinition, error) {
+	definition := &formDefinition{
+		Form: definitionForm{
+			ID:                form.ID,
+			UserID:            form.UserID,
+			OrganizationID:    form.OrganizationID,
+			Slug:              form.Slug,
+			Title:             form.Title,
+			Description:       form.Description,
+			Status:            form.Status,
+			Settings:          form.Settings,
+			ThrottledAt:       form.ThrottledAt,
+			CreatedAt:         form.CreatedAt,
+			UpdatedAt:         form.UpdatedAt,
+			RetentionDays:     form.RetentionDays,
+			DefaultLocale:     form.DefaultLocale,
+			Translations:      form.Translations,
+			Rules:             form.Rules,
+			Version:           form.Version,
+			CollaboratorCount: form.CollaboratorCount,
+		},
+		Questions: make([]definitionQuestion, len(questions)),
+	}
+	for i, q := range questions {
+		definition.Questions[i] = definitionQuestion{
+			ID:                q.ID,
+			Type:              q.Type,
+			Key:               q.Key,
+			Title:             q.Title,
+			Description:       q.Description,
+			Order:             q.Order,
+			Options:           q.Options,
+			Validation:        q.Validation,
+			Display:           q.Display,
+			ResultsVisibility: q.ResultsVisibility,
+			LibraryQuestionID: q.LibraryQuestionID,
+			LibraryVersion:    q.LibraryVersion,
+			CreatedAt:         q.CreatedAt,
+			UpdatedAt:         q.UpdatedAt,
+		}
+	}
+
+	data, err := json.Marshal(definition)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	definition.ETag = `"` + hex.EncodeToString(sum[:16]) + `"`
+	return definition, nil
+}
+
+// models rebuilds the form and questions of the definition
+func (d *formDefinition) models() (*models.Form, []*models.Question) {
+	f := d.Form
+	form := &models.Form{
+		ID:                f.ID,
+		UserID:            f.UserID,
+		OrganizationID:    f.OrganizationID,
+		Slug:              f.Slug,
+		Title:             f.Title,
+		Description:       f.Description,
+		Status:            f.Status,
+		Settings:          f.Settings,
+		ThrottledAt:       f.ThrottledAt,
+		CreatedAt:         f.CreatedAt,
+		UpdatedAt:         f.UpdatedAt,
+		RetentionDays:     f.RetentionDays,
+		DefaultLocale:     f.DefaultLocale,
+		Translations:      f.Translations,
+		Rules:             f.Rules,
+		Version:           f.Version,
+		QuestionCount:     len(d.Questions),
+		CollaboratorCount: f.CollaboratorCount,
+	}
+	questions := make([]*models.Question, len(d.Questions))
+	for i, q := range d.Questions {
+		questions[i] = &models.Question{
+			ID:                q.ID,
+			FormID:            f.ID,
+			Type:              q.Type,
+			Key:               q.Key,
+			Title:             q.Title,
+			Description:       q.Description,
+			Order:             q.Order,
+			Options:           q.Options,
+			Validation:        q.Validation,
+			Display:           q.Display,
+			ResultsVisibility: q.ResultsVisibility,
+			LibraryQuestionID: q.LibraryQuestionID,
+			LibraryVersion:    q.LibraryVersion,
+			CreatedAt:         q.CreatedAt,
+			UpdatedAt:         q.UpdatedAt,
+		}
+	}
+	return form, questions
+}
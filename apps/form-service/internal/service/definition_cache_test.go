@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+)
+
+// memoryDefinitionCache is a definition cache in memory
+type memoryDefinitionCache struct {
+	mu       sync.Mutex
+	versions map[uuid.UUID]int
+	data     map[string][]byte
+	gets     atomic.Int32
+}
+
+func newMemoryDefinitionCache() *memoryDefinitionCache {
+	return &memoryDefinitionCache{versions: make(map[uuid.UUID]int), data: make(map[string][]byte)}
+}
+
+func definitionKey(formID uuid.UUID, version int) string {
+	return fmt.Sprintf("%s:%d", formID, version)
+}
+
+func (c *memoryDefinitionCache) Get(_ context.Context, formID uuid.UUID, version int) ([]byte, error) {
+	c.gets.Add(1)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if version == 0 {
+		current, ok := c.versions[formID]
+		if !ok {
+			return nil, repository.ErrDefinitionNotCached
+		}
+		version = current
+	}
+	data, ok := c.data[definitionKey(formID, version)]
+	if !ok {
+		return nil, repository.ErrDefinitionNotCached
+	}
+	return data, nil
+}
+
+func (c *memoryDefinitionCache) Set(_ context.Context, formID uuid.UUID, version int, data []byte, _ time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.versions[formID] = version
+	c.data[definitionKey(formID, version)] = data
+	return nil
+}
+
+func (c *memoryDefinitionCache) Delete(_ context.Context, formID uuid.UUID) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if version, ok := c.versions[formID]; ok {
+		delete(c.data, definitionKey(formID, version))
+		delete(c.versions, formID)
+	}
+	return nil
+}
+
+// countingQuestions counts the loads of the questions of a form, holding
+// them until release is closed when it is set
+type countingQuestions struct {
+	*memoryQuestions
+	loads   atomic.Int32
+	release chan struct{}
+}
+
+func (r *countingQuestions) GetByFormID(ctx context.Context, formID uuid.UUID) ([]*models.Question, error) {
+	r.loads.Add(1)
+	if r.release != nil {
+		<-r.release
+	}
+	return r.memoryQuestions.GetByFormID(ctx, formID)
+}
+
+type definitionFixture struct {
+	svc       *formService
+	questions *countingQuestions
+	form      *models.Form
+	owner     uuid.UUID
+}
+
+func newDefinitionFixture(t *testing.T, store repository.DefinitionCache, metrics *DefinitionCacheMetrics) *definitionFixture {
+	t.Helper()
+	repos := newMemoryStore(time.Now())
+	questions := &countingQuestions{memoryQuestions: repos.questions}
+	definitions := NewDefinitionCache(store, time.Minute, metrics)
+	svc := NewFormService(repos.forms, questions, nil, repos.orgs, events.LogPublisher{}, events.LogAuditor{}, nil, nil, nil, definitions).(*formService)
+
+	owner := uuid.New()
+	form, err := svc.CreateForm(context.Background(), owner, CreateFormRequest{Title: "Feedback"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.AddQuestion(context.Background(), form.ID, owner, AddQuestionRequest{Type: models.QuestionTypeText, Title: "Name"}); err != nil {
+		t.Fatal(err)
+	}
+	return &definitionFixture{svc: svc, questions: questions, form: form, owner: owner}
+}
+
+// fetch gets the published form, counting the loads of its questions
+func (f *definitionFixture) fetch(t *testing.T) (*PublishedForm, int32) {
+	t.Helper()
+	before := f.questions.loads.Load()
+	published, err := f.svc.GetPublishedForm(context.Background(), f.form.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return published, f.questions.loads.Load() - before
+}
+
+func TestDefinitionCache(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryDefinitionCache()
+	metrics := NewDefinitionCacheMetrics(prometheus.NewRegistry())
+	f := newDefinitionFixture(t, store, metrics)
+
+	// A stale definition cached before the form is published is dropped
+	stale, _ := newFormDefinition(&models.Form{ID: f.form.ID, Title: "Stale", Status: models.FormStatusPublished, Version: 1}, nil)
+	data, _ := json.Marshal(stale)
+	store.Set(ctx, f.form.ID, 1, data, time.Minute)
+	if _, _, err := f.svc.PublishForm(ctx, f.form.ID, f.owner); err != nil {
+		t.Fatal(err)
+	}
+
+	first, loads := f.fetch(t)
+	if loads != 1 || first.Form.Title != "Feedback" || len(first.Questions) != 1 || first.ETag == "" {
+		t.Fatalf("first fetch: %d loads, %+v; want the form loaded from the database", loads, first.Form)
+	}
+	second, loads := f.fetch(t)
+	if loads != 0 {
+		t.Errorf("second fetch loaded the questions %d times, want it served from the cache", loads)
+	}
+	if second.ETag != first.ETag || second.Form.Version != first.Form.Version || second.Questions[0].ID != first.Questions[0].ID {
+		t.Errorf("cached definition %+v differs from the loaded one %+v", second, first)
+	}
+
+	// Fetches of a known version are served from the cache too
+	before := f.questions.loads.Load()
+	if _, err := f.svc.published(ctx, first.Form); err != nil || f.questions.loads.Load() != before {
+		t.Errorf("fetch of version %d: %v, %d loads; want a hit", first.Form.Version, err, f.questions.loads.Load()-before)
+	}
+
+	title := "Customer feedback"
+	if _, err := f.svc.UpdateForm(ctx, f.form.ID, f.owner, UpdateFormRequest{Title: &title}); err != nil {
+		t.Fatal(err)
+	}
+	updated, loads := f.fetch(t)
+	if loads != 1 || updated.Form.Title != title || updated.ETag == first.ETag {
+		t.Errorf("after an update: %d loads, title %q, ETag %s; want the new definition", loads, updated.Form.Title, updated.ETag)
+	}
+
+	if _, err := f.svc.AddQuestion(ctx, f.form.ID, f.owner, AddQuestionRequest{Type: models.QuestionTypeText, Title: "Email", Order: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if added, _ := f.fetch(t); len(added.Questions) != 2 || added.ETag == updated.ETag {
+		t.Errorf("after adding a question: %d questions, ETag %s; want the new definition", len(added.Questions), added.ETag)
+	}
+
+	if err := f.svc.DeleteForm(ctx, f.form.ID, f.owner); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.svc.GetPublishedForm(ctx, f.form.ID); !errors.Is(err, ErrFormNotFound) {
+		t.Errorf("deleted form: err = %v, want ErrFormNotFound", err)
+	}
+
+	if hits := testutil.ToFloat64(metrics.Lookups.WithLabelValues("hit")); hits != 2 {
+		t.Errorf("%v hits counted, want 2", hits)
+	}
+}
+
+func TestDefinitionCacheSharesConcurrentLoads(t *testing.T) {
+	store := newMemoryDefinitionCache()
+	f := newDefinitionFixture(t, store, nil)
+	if _, _, err := f.svc.PublishForm(context.Background(), f.form.ID, f.owner); err != nil {
+		t.Fatal(err)
+	}
+
+	const fetches = 8
+	before := f.questions.loads.Load()
+	f.questions.release = make(chan struct{})
+	var wg sync.WaitGroup
+	etags := make([]string, fetches)
+	for i := 0; i < fetches; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			published, err := f.svc.GetPublishedForm(context.Background(), f.form.ID)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			etags[i] = published.ETag
+		}(i)
+	}
+	// Every fetch missed the cache once it has been read fetches times
+	for store.gets.Load() < fetches {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(f.questions.release)
+	wg.Wait()
+
+	if loads := f.questions.loads.Load() - before; loads != 1 {
+		t.Errorf("%d concurrent misses loaded the questions %d times, want once", fetches, loads)
+	}
+	for _, etag := range etags {
+		if etag != etags[0] {
+			t.Errorf("fetches served different definitions: %v", etags)
+			break
+		}
+	}
+}
+
+func TestDefinitionCacheUnavailable(t *testing.T) {
+	ctx := context.Background()
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1, DialTimeout: 100 * time.Millisecond})
+	defer client.Close()
+	metrics := NewDefinitionCacheMetrics(prometheus.NewRegistry())
+	f := newDefinitionFixture(t, repository.NewRedisDefinitionCache(client), metrics)
+
+	if _, _, err := f.svc.PublishForm(ctx, f.form.ID, f.owner); err != nil {
+		t.Fatalf("publishing with Redis down: %v", err)
+	}
+	first, loads := f.fetch(t)
+	if loads != 1 || first.Form.Title != "Feedback" || len(first.Questions) != 1 {
+		t.Errorf("fetch with Redis down: %d loads, %+v; want the form from the database", loads, first.Form)
+	}
+	title := "Customer feedback"
+	if _, err := f.svc.UpdateForm(ctx, f.form.ID, f.owner, UpdateFormRequest{Title: &title}); err != nil {
+		t.Fatalf("updating with Redis down: %v", err)
+	}
+	if updated, _ := f.fetch(t); updated.Form.Title != title || updated.ETag == first.ETag {
+		t.Errorf("fetch after an update with Redis down: %+v", updated.Form)
+	}
+
+	if errs := testutil.ToFloat64(metrics.Lookups.WithLabelValues("error")); errs != 2 {
+		t.Errorf("%v errors counted, want 2", errs)
+	}
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+)
+
+var (
+	// ErrDraftRejected is returned when a draft can't be saved for the form or owner
+	ErrDraftRejected = errors.New("draft rejected")
+	// ErrDraftTooLarge is returned for answers larger than models.MaxDraftSizeBytes
+	ErrDraftTooLarge = fmt.Errorf("%w: answers exceed %d bytes", ErrDraftRejected, models.MaxDraftSizeBytes)
+)
+
+// DraftService defines the interface for autosaved response drafts. Drafts
+// are kept apart from responses, so they never count toward response totals,
+// analytics or quotas.
+type DraftService interface {
+	SaveDraft(ctx context.Context, formID uuid.UUID, owner models.DraftOwner, req SaveDraftRequest) (*models.ResponseDraft, error)
+	GetDraft(ctx context.Context, formID uuid.UUID, owner models.DraftOwner) (*models.ResponseDraft, error)
+	// ConsumeDraft removes and returns the draft when the response is finally submitted
+	ConsumeDraft(ctx context.Context, formID uuid.UUID, owner models.DraftOwner) (*models.ResponseDraft, error)
+	CleanupExpiredDrafts(ctx context.Context) (int64, error)
+	RunDraftCleanup(ctx context.Context, interval time.Duration)
+}
+
+// SaveDraftRequest represents an autosave of partial answers
+type SaveDraftRequest struct {
+	Answers json.RawMessage `json:"answers" binding:"required"`
+}
+
+// ConsumeDraftRequest identifies the draft consumed by a final submission
+type ConsumeDraftRequest struct {
+	UserID          string `json:"user_id"`
+	RespondentToken string `json:"respondent_token"`
+}
+
+// draftService implements DraftService interface
+type draftService struct {
+	formRepo  repository.FormRepository
+	cache     repository.DraftCache
+	draftRepo repository.DraftRepository
+	ttl       time.Duration
+	now       func() time.Time
+}
+
+// NewDraftService creates a new draft service instance. Drafts are stored in
+// cache for ttl; drafts of logged-in users are written through to draftRepo.
+func NewDraftService(formRepo repository.FormRepository, cache repository.DraftCache, draftRepo repository.DraftRepository, ttl time.Duration) DraftService {
+	if ttl <= 0 {
+		ttl = models.DefaultDraftTTL
+	}
+
+	return &draftService{
+		formRepo:  formRepo,
+		cache:     cache,
+		draftRepo: draftRepo,
+		ttl:       ttl,
+		now:       time.Now,
+	}
+}
+
+// SaveDraft upserts the owner's draft for a published form, restarting its TTL
+func (s *draftService) SaveDraft(ctx context.Context, formID uuid.UUID, owner models.DraftOwner, req SaveDraftRequest) (*models.ResponseDraft, error) {
+	ownerKey := owner.Key()
+	if ownerKey == "" {
+		return nil, fmt.Errorf("%w: a respondent token or login is required", ErrDraftRejected)
+	}
+	if len(req.Answers) > models.MaxDraftSizeBytes {
+		return nil, ErrDraftTooLarge
+	}
+	if !bytes.HasPrefix(bytes.TrimSpace(req.Answers), []byte("{")) {
+		return nil, fmt.Errorf("%w: answers must be a JSON object", ErrDraftRejected)
+	}
+
+	form, err := s.formRepo.GetByID(ctx, formID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get form: %w", err)
+	}
+	if form.Status != models.FormStatusPublished {
+		return nil, fmt.Errorf("%w: form is not accepting responses", ErrDraftRejected)
+	}
+	settings, err := form.GetSettings()
+	if err != nil {
+		return nil, err
+	}
+	if settings.ResponsePolicy().RequiresLogin && owner.UserID == "" {
+		return nil, fmt.Errorf("%w: this form requires login", ErrDraftRejected)
+	}
+
+	now := s.now().UTC()
+	draft := &models.ResponseDraft{
+		FormID:    formID,
+		OwnerKey:  ownerKey,
+		UserID:    owner.UserID,
+		Answers:   []byte(req.Answers),
+		SizeBytes: len(req.Answers),
+		UpdatedAt: now,
+		ExpiresAt: now.Add(s.ttl),
+	}
+
+	// Anonymous drafts only live in the cache. A user's draft is safe once
+	// written through, so a cache failure is not fatal for it.
+	cacheErr := s.cache.Set(ctx, draft)
+	if owner.UserID == "" {
+		if cacheErr != nil {
+			return nil, fmt.Errorf("failed to save draft: %w", cacheErr)
+		}
+		return draft, nil
+	}
+	if err := s.draftRepo.Upsert(ctx, draft); err != nil {
+		return nil, fmt.Errorf("failed to save draft: %w", err)
+	}
+	if cacheErr != nil {
+		log.Printf("Failed to cache draft of form %s: %v", formID, cacheErr)
+	}
+
+	return draft, nil
+}
+
+// GetDraft retrieves the owner's draft, falling back to the write-through
+// copy when a user's draft was evicted from the cache
+func (s *draftService) GetDraft(ctx context.Context, formID uuid.UUID, owner models.DraftOwner) (*models.ResponseDraft, error) {
+	ownerKey := owner.Key()
+	if ownerKey == "" {
+		return nil, repository.ErrDraftNotFound
+	}
+
+	draft, err := s.cache.Get(ctx, formID, ownerKey)
+	if err == nil || owner.UserID == "" {
+		return draft, err
+	}
+	if !errors.Is(err, repository.ErrDraftNotFound) {
+		log.Printf("Failed to read cached draft of form %s: %v", formID, err)
+	}
+
+	draft, err = s.draftRepo.Get(ctx, formID, ownerKey, s.now())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.cache.Set(ctx, draft); err != nil {
+		log.Printf("Failed to re-cache draft of form %s: %v", formID, err)
+	}
+
+	return draft, nil
+}
+
+// ConsumeDraft atomically takes the draft out of every store it lives in, so
+// a draft is handed to at most one submission
+func (s *draftService) ConsumeDraft(ctx context.Context, formID uuid.UUID, owner models.DraftOwner) (*models.ResponseDraft, error) {
+	ownerKey := owner.Key()
+	if ownerKey == "" {
+		return nil, repository.ErrDraftNotFound
+	}
+
+	draft, err := s.cache.Take(ctx, formID, ownerKey)
+	if err != nil && !errors.Is(err, repository.ErrDraftNotFound) {
+		return nil, fmt.Errorf("failed to consume draft: %w", err)
+	}
+	if owner.UserID == "" {
+		return draft, err
+	}
+
+	stored, storedErr := s.draftRepo.Take(ctx, formID, ownerKey, s.now())
+	if storedErr != nil && !errors.Is(storedErr, repository.ErrDraftNotFound) {
+		return nil, fmt.Errorf("failed to consume draft: %w", storedErr)
+	}
+	if draft == nil {
+		return stored, storedErr
+	}
+
+	return draft, nil
+}
+
+// CleanupExpiredDrafts removes expired write-through drafts; cached drafts
+// expire on their own
+func (s *draftService) CleanupExpiredDrafts(ctx context.Context) (int64, error) {
+	removed, err := s.draftRepo.DeleteExpired(ctx, s.now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired drafts: %w", err)
+	}
+	return removed, nil
+}
+
+// RunDraftCleanup runs CleanupExpiredDrafts every interval until ctx is cancelled
+func (s *draftService) RunDraftCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			removed, err := s.CleanupExpiredDrafts(ctx)
+			if err != nil {
+				log.Printf("Draft cleanup failed: %v", err)
+				continue
+			}
+			if removed > 0 {
+				log.Printf("Removed %d expired drafts", removed)
+			}
+		}
+	}
+}
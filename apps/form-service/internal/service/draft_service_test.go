@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+)
+
+// memoryDrafts implements both draft stores in memory
+type memoryDrafts struct {
+	mu     sync.Mutex
+	drafts map[string]models.ResponseDraft
+	now    func() time.Time
+}
+
+func newMemoryDrafts(now func() time.Time) *memoryDrafts {
+	return &memoryDrafts{drafts: make(map[string]models.ResponseDraft), now: now}
+}
+
+func (m *memoryDrafts) key(formID uuid.UUID, ownerKey string) string {
+	return formID.String() + "/" + ownerKey
+}
+
+func (m *memoryDrafts) lookup(formID uuid.UUID, ownerKey string, remove bool) (*models.ResponseDraft, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	draft, ok := m.drafts[m.key(formID, ownerKey)]
+	if remove {
+		delete(m.drafts, m.key(formID, ownerKey))
+	}
+	if !ok || draft.IsExpired(m.now()) {
+		return nil, repository.ErrDraftNotFound
+	}
+	return &draft, nil
+}
+
+func (m *memoryDrafts) store(draft *models.ResponseDraft) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.drafts[m.key(draft.FormID, draft.OwnerKey)] = *draft
+	return nil
+}
+
+func (m *memoryDrafts) count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.drafts)
+}
+
+// memoryDraftCache adapts memoryDrafts to repository.DraftCache
+type memoryDraftCache struct{ *memoryDrafts }
+
+func (c memoryDraftCache) Get(_ context.Context, formID uuid.UUID, ownerKey string) (*models.ResponseDraft, error) {
+	return c.lookup(formID, ownerKey, false)
+}
+
+func (c memoryDraftCache) Set(_ context.Context, draft *models.ResponseDraft) error {
+	return c.store(draft)
+}
+
+func (c memoryDraftCache) Take(_ context.Context, formID uuid.UUID, ownerKey string) (*models.ResponseDraft, error) {
+	return c.lookup(formID, ownerKey, true)
+}
+
+// memoryDraftRepository adapts memoryDrafts to repository.DraftRepository
+type memoryDraftRepository struct{ *memoryDrafts }
+
+func (r memoryDraftRepository) Upsert(_ context.Context, draft *models.ResponseDraft) error {
+	return r.store(draft)
+}
+
+func (r memoryDraftRepository) Get(_ context.Context, formID uuid.UUID, ownerKey string, _ time.Time) (*models.ResponseDraft, error) {
+	return r.lookup(formID, ownerKey, false)
+}
+
+func (r memoryDraftRepository) Take(_ context.Context, formID uuid.UUID, ownerKey string, _ time.Time) (*models.ResponseDraft, error) {
+	return r.lookup(formID, ownerKey, true)
+}
+
+func (r memoryDraftRepository) Abandoned(_ context.Context, formID uuid.UUID, idleSince, now time.Time, limit int) ([]models.ResponseDraft, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var drafts []models.ResponseDraft
+	for _, draft := range r.drafts {
+		if draft.FormID == formID && draft.UpdatedAt.Before(idleSince) && !draft.IsExpired(now) && len(drafts) < limit {
+			drafts = append(drafts, draft)
+		}
+	}
+	return drafts, nil
+}
+
+func (r memoryDraftRepository) DeleteExpired(_ context.Context, now time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var removed int64
+	for key, draft := range r.drafts {
+		if draft.IsExpired(now) {
+			delete(r.drafts, key)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+type draftFixture struct {
+	*memoryStore
+	service *draftService
+	cache   *memoryDrafts
+	stored  *memoryDrafts
+	form    *models.Form
+}
+
+func newDraftFixture(t *testing.T, mode models.ResponseMode) *draftFixture {
+	t.Helper()
+
+	settings, err := json.Marshal(models.FormSettings{AcceptingResponses: true, ResponseMode: mode})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &draftFixture{memoryStore: newMemoryStore(time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC))}
+	f.form = f.createForm(t, &models.Form{Title: "Survey", Status: models.FormStatusPublished, Settings: settings})
+	f.cache, f.stored = newMemoryDrafts(f.clock.now), newMemoryDrafts(f.clock.now)
+
+	f.service = NewDraftService(f.forms, memoryDraftCache{f.cache}, memoryDraftRepository{f.stored}, time.Hour).(*draftService)
+	f.service.now = f.clock.now
+	return f
+}
+
+func answers(n int) SaveDraftRequest {
+	return SaveDraftRequest{Answers: json.RawMessage(fmt.Sprintf(`{"q1":"answer %d"}`, n))}
+}
+
+func TestConsumeDraftClearsDraftAfterSubmission(t *testing.T) {
+	owners := map[string]models.DraftOwner{
+		"anonymous": {RespondentToken: "respondent-token"},
+		"user":      {UserID: "user-1"},
+	}
+
+	for name, owner := range owners {
+		t.Run(name, func(t *testing.T) {
+			f := newDraftFixture(t, models.ResponseModeAnonymous)
+			ctx := context.Background()
+
+			if _, err := f.service.SaveDraft(ctx, f.form.ID, owner, answers(1)); err != nil {
+				t.Fatalf("save failed: %v", err)
+			}
+
+			draft, err := f.service.ConsumeDraft(ctx, f.form.ID, owner)
+			if err != nil {
+				t.Fatalf("consume failed: %v", err)
+			}
+			if string(draft.Answers) != `{"q1":"answer 1"}` {
+				t.Errorf("unexpected answers: %s", draft.Answers)
+			}
+
+			if _, err := f.service.GetDraft(ctx, f.form.ID, owner); !errors.Is(err, repository.ErrDraftNotFound) {
+				t.Errorf("expected the draft to be gone after submission, got %v", err)
+			}
+			if _, err := f.service.ConsumeDraft(ctx, f.form.ID, owner); !errors.Is(err, repository.ErrDraftNotFound) {
+				t.Errorf("expected a second submission to find no draft, got %v", err)
+			}
+			if f.cache.count() != 0 || f.stored.count() != 0 {
+				t.Errorf("expected both stores to be empty, got %d cached and %d stored", f.cache.count(), f.stored.count())
+			}
+		})
+	}
+}
+
+func TestDraftsDoNotCountTowardResponseQuota(t *testing.T) {
+	// A one_per_user form on a plan of one response a month; autosaving must
+	// use up neither
+	f := newDraftFixture(t, models.ResponseModeOnePerUser)
+	ctx := context.Background()
+	owner := models.DraftOwner{UserID: "user-1"}
+
+	f.form.OrganizationID = f.createOrganization(t, uuid.New(), nil).ID
+	if err := f.forms.Update(ctx, f.form); err != nil {
+		t.Fatal(err)
+	}
+	meter := NewUsageMeter(newMemoryUsageCounter(), newMemoryUsageRepository(f.forms), f.forms, f.orgs, map[string]models.Plan{
+		models.DefaultPlan: {MaxResponsesPerMonth: 1},
+	}, true)
+	meter.now = f.clock.now
+	responsesUsed := func() int64 {
+		t.Helper()
+		usage, err := meter.current(ctx, UsageKeyOf(f.form.OrganizationID, f.clock.now()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return usage.Responses
+	}
+
+	for i := 1; i <= 50; i++ {
+		if _, err := f.service.SaveDraft(ctx, f.form.ID, owner, answers(i)); err != nil {
+			t.Fatalf("autosave %d failed: %v", i, err)
+		}
+	}
+
+	if f.cache.count() != 1 || f.stored.count() != 1 {
+		t.Fatalf("expected autosaves to keep a single draft, got %d cached and %d stored", f.cache.count(), f.stored.count())
+	}
+
+	draft, err := f.service.GetDraft(ctx, f.form.ID, owner)
+	if err != nil {
+		t.Fatalf("resume failed: %v", err)
+	}
+	if string(draft.Answers) != `{"q1":"answer 50"}` {
+		t.Errorf("expected the latest autosave, got %s", draft.Answers)
+	}
+
+	if _, err := f.service.ConsumeDraft(ctx, f.form.ID, owner); err != nil {
+		t.Fatalf("consume failed: %v", err)
+	}
+	if used := responsesUsed(); used != 0 {
+		t.Fatalf("expected drafts to leave the monthly responses at 0, got %d", used)
+	}
+	// The submission the draft was consumed for is the one response counted
+	if err := meter.admitResponse(ctx, f.form, "response-1"); err != nil {
+		t.Fatalf("expected the submission to be admitted after 50 autosaves, got %v", err)
+	}
+	if used := responsesUsed(); used != 1 {
+		t.Errorf("expected the submission to be the only response counted, got %d", used)
+	}
+	if err := meter.admitResponse(ctx, f.form, "response-2"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("expected a second response to exceed the quota, got %v", err)
+	}
+
+	if _, err := f.service.SaveDraft(ctx, f.form.ID, models.DraftOwner{RespondentToken: "anonymous"}, answers(1)); !errors.Is(err, ErrDraftRejected) {
+		t.Errorf("expected anonymous drafts to be rejected on a login-only form, got %v", err)
+	}
+}
+
+func TestDraftSurvivesCacheEviction(t *testing.T) {
+	f := newDraftFixture(t, models.ResponseModeAnonymous)
+	ctx := context.Background()
+	user := models.DraftOwner{UserID: "user-1"}
+	anonymous := models.DraftOwner{RespondentToken: "respondent-token"}
+
+	for _, owner := range []models.DraftOwner{user, anonymous} {
+		if _, err := f.service.SaveDraft(ctx, f.form.ID, owner, answers(1)); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+	}
+
+	// Redis evicts everything
+	f.cache.drafts = make(map[string]models.ResponseDraft)
+
+	if _, err := f.service.GetDraft(ctx, f.form.ID, user); err != nil {
+		t.Errorf("expected the user's draft to be restored from PostgreSQL, got %v", err)
+	}
+	if f.cache.count() != 1 {
+		t.Errorf("expected the restored draft to be cached again")
+	}
+	if _, err := f.service.GetDraft(ctx, f.form.ID, anonymous); !errors.Is(err, repository.ErrDraftNotFound) {
+		t.Errorf("expected anonymous drafts to live only in the cache, got %v", err)
+	}
+}
+
+func TestSaveDraftRejectsOversizedAnswers(t *testing.T) {
+	f := newDraftFixture(t, models.ResponseModeAnonymous)
+	owner := models.DraftOwner{RespondentToken: "respondent-token"}
+
+	blob := `{"q1":"` + strings.Repeat("x", models.MaxDraftSizeBytes) + `"}`
+	_, err := f.service.SaveDraft(context.Background(), f.form.ID, owner, SaveDraftRequest{Answers: json.RawMessage(blob)})
+	if !errors.Is(err, ErrDraftTooLarge) {
+		t.Fatalf("expected ErrDraftTooLarge, got %v", err)
+	}
+	if f.cache.count() != 0 {
+		t.Error("expected nothing to be stored")
+	}
+}
+
+func TestExpiredDraftsAreNotResumed(t *testing.T) {
+	f := newDraftFixture(t, models.ResponseModeIdentified)
+	ctx := context.Background()
+	owner := models.DraftOwner{UserID: "user-1"}
+
+	if _, err := f.service.SaveDraft(ctx, f.form.ID, owner, answers(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	f.clock.advance(2 * time.Hour)
+
+	if _, err := f.service.GetDraft(ctx, f.form.ID, owner); !errors.Is(err, repository.ErrDraftNotFound) {
+		t.Errorf("expected the expired draft to be gone, got %v", err)
+	}
+	removed, err := f.service.CleanupExpiredDrafts(ctx)
+	if err != nil || removed != 1 {
+		t.Errorf("expected cleanup to remove 1 draft, got %d (%v)", removed, err)
+	}
+}
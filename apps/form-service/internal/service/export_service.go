@@ -0,0 +1,1023 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/access"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/analytics"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/download"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+	"github.com/Mir00r/X-Form-Backend/shared/storage"
+)
+
+const (
+	// exportBatchSize is the number of responses read per page
+	exportBatchSize = 100
+	// exportStaleAfter is how long a running job may go without saving its
+	// progress before another replica takes it over
+	exportStaleAfter = 15 * time.Minute
+)
+
+var (
+	// ErrExportInvalid is returned for exports that can't be started
+	ErrExportInvalid = errors.New("invalid export")
+	// ErrExportTooLarge fails the exports growing past the maximum size
+	ErrExportTooLarge = errors.New("export exceeds the maximum size")
+	// ErrExportNotReady is returned when downloading exports that have not
+	// completed
+	ErrExportNotReady = errors.New("export is not completed")
+	// ErrDownloadLinkUsed is returned when a single-use download link is
+	// used again
+	ErrDownloadLinkUsed = errors.New("download link was already used")
+)
+
+// ExportService defines the interface for the response exports of forms.
+// Exports run as jobs, claimed by a single replica, which streams the
+// export to storage as it writes it.
+type ExportService interface {
+	// Start queues the export of the responses of a form
+	Start(ctx context.Context, formID, userID uuid.UUID, req ExportRequest) (*models.ExportJob, error)
+	// GetJob returns an export of a form with its progress, its place in
+	// the queue while queued, and where to download it once completed
+	GetJob(ctx context.Context, formID, jobID, userID uuid.UUID) (*models.ExportJob, error)
+	// DownloadLink signs a short-lived link to download a completed export
+	// of a form without authentication
+	DownloadLink(ctx context.Context, formID, jobID, userID uuid.UUID) (*ExportDownload, error)
+	// OpenDownload opens a completed export of a form, for deployments
+	// streaming exports through the service
+	OpenDownload(ctx context.Context, formID, jobID, userID uuid.UUID) (*ExportArtifact, error)
+	// RedeemDownload opens the export of a form a single-use download link
+	// was signed for, and records the link as used unless the request
+	// resumes the download of its redemption
+	RedeemDownload(ctx context.Context, formID, jobID uuid.UUID, token string, resume DownloadResume) (*ExportArtifact, error)
+	// Cancel cancels a queued or running export of a form. A running export
+	// stops at its next batch of responses and what it wrote is deleted.
+	Cancel(ctx context.Context, formID, jobID, userID uuid.UUID) (*models.ExportJob, error)
+	// RunJobs runs the queued jobs, checking for them every interval until
+	// ctx is cancelled
+	RunJobs(ctx context.Context, interval time.Duration)
+}
+
+// ExportRequest starts an export
+type ExportRequest struct {
+	Format models.ExportFormat `json:"format" enums:"csv,xlsx" example:"csv"`
+	// IncludeFiles bundles the files answering file questions with the
+	// responses in a ZIP archive
+	IncludeFiles bool `json:"include_files"`
+	// IncludeLabels adds a second header row naming each column by the title
+	// of its question, under the first naming it by the key of the question
+	IncludeLabels bool `json:"include_labels"`
+	// IncludePresentedOrder adds, after each question with randomized
+	// options, a column of the option keys in the order the respondent saw
+	// them
+	IncludePresentedOrder bool `json:"include_presented_order"`
+	// IncludeTest exports the test submissions of owners and collaborators
+	// too, flagged in a test column
+	IncludeTest bool `json:"include_test"`
+	// IncludeTimings adds, after each question, the seconds it held focus
+	// and the times the respondent returned to it. Timings are left out of
+	// exports unless asked for; responses submitted without them have
+	// empty timing cells.
+	IncludeTimings bool `json:"include_timings"`
+	// Priority is normal unless set; low priority exports only run when no
+	// normal export can run instead
+	Priority models.ExportPriority `json:"priority,omitempty" enums:"normal,low" example:"normal"`
+}
+
+// ExportConfig bounds the exports
+type ExportConfig struct {
+	// MaxArchiveBytes fails the exports growing past it
+	MaxArchiveBytes int64
+	// LinkTTL is how long the download links of exports last
+	LinkTTL time.Duration
+	// SigningSecret signs the single-use download links
+	SigningSecret string
+	// SingleUseLinks makes the download links work once, resumed only by
+	// the client that used them, rather than as often as needed until they
+	// expire
+	SingleUseLinks bool
+	// MaxConcurrentJobs caps the exports running across replicas, and on
+	// each replica
+	MaxConcurrentJobs int
+	// MaxJobsPerOrganization caps the exports of an organization running
+	// at a time; its other exports stay queued
+	MaxJobsPerOrganization int
+}
+
+// ExportDownload is a signed link to download an export
+type ExportDownload struct {
+	// URL downloads the export without authentication, Range requests
+	// included; it may point at the object storage
+	URL       string    `json:"url" example:"/api/v1/public/forms/6f1c.../exports/0b9f.../download?token=..."`
+	ExpiresAt time.Time `json:"expires_at"`
+	// SingleUse links download once; the client that used them may resume
+	// the download with Range requests until they expire
+	SingleUse bool `json:"single_use"`
+}
+
+// DownloadResume identifies a request resuming the download of a
+// single-use link: the redemption handed out with its first download, and
+// the first byte the request asks for
+type DownloadResume struct {
+	Redemption string
+	Offset     int64
+}
+
+// ExportArtifact is an export opened for download. Body is seekable when
+// storage allows it, so that Range requests can be served; otherwise
+// RedirectURL, when set, downloads it from storage directly instead.
+type ExportArtifact struct {
+	Name        string
+	ContentType string
+	Body        io.ReadCloser
+	RedirectURL string
+	// Redemption is set when a single-use link was redeemed, for the client
+	// to resume the download with until the link expires
+	Redemption string
+	ExpiresAt  time.Time
+}
+
+// ExportMetrics exposes the scheduling of export jobs
+type ExportMetrics struct {
+	QueueDepth *prometheus.GaugeVec
+	Wait       prometheus.Histogram
+}
+
+// NewExportMetrics creates and registers the export metrics
+func NewExportMetrics(reg prometheus.Registerer) *ExportMetrics {
+	m := &ExportMetrics{
+		QueueDepth: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "form_service_export_queue_depth",
+			Help: "Export jobs queued, by organization",
+		}, []string{"organization"}),
+		Wait: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "form_service_export_wait_seconds",
+			Help:    "Time export jobs waited from being queued to starting",
+			Buckets: []float64{1, 5, 15, 30, 60, 300, 900, 1800, 3600, 4 * 3600},
+		}),
+	}
+	if reg != nil {
+		reg.MustRegister(m.QueueDepth, m.Wait)
+	}
+	return m
+}
+
+// observeQueue sets the queue depths, clearing the organizations whose
+// queue emptied
+func (m *ExportMetrics) observeQueue(depths map[uuid.UUID]int) {
+	if m == nil {
+		return
+	}
+	m.QueueDepth.Reset()
+	for orgID, depth := range depths {
+		m.QueueDepth.WithLabelValues(orgID.String()).Set(float64(depth))
+	}
+}
+
+// observeWait records how long a job waited to start
+func (m *ExportMetrics) observeWait(job *models.ExportJob) {
+	if m == nil || job.StartedAt == nil {
+		return
+	}
+	m.Wait.Observe(job.StartedAt.Sub(job.CreatedAt).Seconds())
+}
+
+// exportService implements ExportService interface
+type exportService struct {
+	formRepo     repository.FormRepository
+	questionRepo repository.QuestionRepository
+	jobs         repository.ExportJobRepository
+	responses    analytics.ResponseReader
+	uploads      repository.FileUploadRepository
+	storage      storage.Backend
+	guard        formGuard
+	config       ExportConfig
+	usage        *UsageMeter
+	metrics      *ExportMetrics
+	redemptions  repository.LinkRedemptions
+	now          func() time.Time
+}
+
+// NewExportService creates a new export service instance. Responses are
+// read from responses; without one, exports can't be started. Exports
+// started are metered by usage, and their scheduling exposed in metrics,
+// both of which may be nil. Single-use download links are redeemed in
+// redemptions, required with config.SingleUseLinks.
+func NewExportService(formRepo repository.FormRepository, questionRepo repository.QuestionRepository, collaboratorRepo repository.CollaboratorRepository, orgRepo repository.OrganizationRepository, jobs repository.ExportJobRepository, responses analytics.ResponseReader, uploads repository.FileUploadRepository, store storage.Backend, config ExportConfig, usage *UsageMeter, metrics *ExportMetrics, redemptions repository.LinkRedemptions) ExportService {
+	return &exportService{
+		formRepo:     formRepo,
+		questionRepo: questionRepo,
+		jobs:         jobs,
+		responses:    responses,
+		uploads:      uploads,
+		storage:      store,
+		guard:        formGuard{forms: formRepo, collaborators: collaboratorRepo, orgs: organizationScope{orgs: orgRepo}},
+		config:       config,
+		usage:        usage,
+		metrics:      metrics,
+		redemptions:  redemptions,
+		now:          time.Now,
+	}
+}
+
+// Start queues the job; a replica running jobs claims it on its next check
+func (s *exportService) Start(ctx context.Context, formID, userID uuid.UUID, req ExportRequest) (*models.ExportJob, error) {
+	if req.Format == "" {
+		req.Format = models.ExportFormatCSV
+	}
+	if !req.Format.IsValid() {
+		return nil, fmt.Errorf("%w: unsupported format %q", ErrExportInvalid, req.Format)
+	}
+	if req.Priority == "" {
+		req.Priority = models.ExportPriorityNormal
+	}
+	if !req.Priority.IsValid() {
+		return nil, fmt.Errorf("%w: unsupported priority %q", ErrExportInvalid, req.Priority)
+	}
+	form, err := s.guard.authorize(ctx, formID, userID, access.View)
+	if err != nil {
+		return nil, err
+	}
+	if s.responses == nil {
+		return nil, analytics.ErrNotConfigured
+	}
+
+	job := &models.ExportJob{
+		FormID:                formID,
+		OrganizationID:        form.OrganizationID,
+		RequestedBy:           userID,
+		Format:                req.Format,
+		IncludeFiles:          req.IncludeFiles,
+		IncludeLabels:         req.IncludeLabels,
+		IncludePresentedOrder: req.IncludePresentedOrder,
+		IncludeTest:           req.IncludeTest,
+		IncludeTimings:        req.IncludeTimings,
+		Priority:              req.Priority,
+		Status:                models.ExportJobQueued,
+	}
+	if err := s.jobs.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to create export job: %w", err)
+	}
+	s.usage.record(ctx, form.OrganizationID, models.UsageExports, job.ID.String(), 1)
+	return job, nil
+}
+
+// GetJob returns the job, pointing completed exports at their download
+// endpoint, which signs the links
+func (s *exportService) GetJob(ctx context.Context, formID, jobID, userID uuid.UUID) (*models.ExportJob, error) {
+	if _, err := s.guard.authorize(ctx, formID, userID, access.View); err != nil {
+		return nil, err
+	}
+	job, err := s.jobs.Get(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.FormID != formID {
+		return nil, repository.ErrExportJobNotFound
+	}
+
+	if job.Status == models.ExportJobQueued {
+		position, err := s.jobs.QueuePosition(ctx, job)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get queue position: %w", err)
+		}
+		job.QueuePosition = position
+	}
+	if job.Status == models.ExportJobCompleted {
+		job.DownloadURL = fmt.Sprintf("/api/v1/forms/%s/exports/%s/download", job.FormID, job.ID)
+	}
+	return job, nil
+}
+
+// DownloadLink signs the link once the caller is known to have access to
+// the form. Reusable links are presigned by storage, so that downloads go
+// to the object storage directly; single-use links point at the public
+// download endpoint, which redeems them.
+func (s *exportService) DownloadLink(ctx context.Context, formID, jobID, userID uuid.UUID) (*ExportDownload, error) {
+	job, err := s.completedJob(ctx, formID, jobID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := s.now().UTC().Add(s.config.LinkTTL).Truncate(time.Second)
+	if !s.config.SingleUseLinks {
+		link, err := s.storage.PresignGet(ctx, job.ObjectKey, s.config.LinkTTL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign export link: %w", err)
+		}
+		return &ExportDownload{URL: link, ExpiresAt: expiresAt}, nil
+	}
+
+	token, err := download.Sign(s.config.SigningSecret, download.Claims{
+		LinkID:    uuid.New(),
+		JobID:     job.ID,
+		Key:       job.ObjectKey,
+		SingleUse: true,
+		ExpiresAt: expiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign export link: %w", err)
+	}
+	return &ExportDownload{
+		URL:       fmt.Sprintf("/api/v1/public/forms/%s/exports/%s/download?token=%s", formID, jobID, url.QueryEscape(token)),
+		ExpiresAt: expiresAt,
+		SingleUse: true,
+	}, nil
+}
+
+// OpenDownload opens the export for whoever can view the form
+func (s *exportService) OpenDownload(ctx context.Context, formID, jobID, userID uuid.UUID) (*ExportArtifact, error) {
+	job, err := s.completedJob(ctx, formID, jobID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.open(ctx, job)
+}
+
+// RedeemDownload checks the token was signed for the export, which must
+// still be completed, then redeems it. A single-use link is redeemed by its
+// first request, whatever it asks for. Later requests are only served when
+// they resume the download of that redemption past its first byte. Storage
+// that can't seek, such as S3, is redirected to with a link lasting a
+// minute rather than streamed.
+func (s *exportService) RedeemDownload(ctx context.Context, formID, jobID uuid.UUID, token string, resume DownloadResume) (*ExportArtifact, error) {
+	claims, err := download.Verify(s.config.SigningSecret, token, s.now())
+	if err != nil {
+		return nil, err
+	}
+	job, err := s.jobs.Get(ctx, jobID)
+	if errors.Is(err, repository.ErrExportJobNotFound) {
+		return nil, download.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Links of an export cancelled, or deleted, since they were signed
+	// stop working
+	if claims.JobID != job.ID || job.FormID != formID || job.Status != models.ExportJobCompleted || claims.Key != job.ObjectKey {
+		return nil, download.ErrInvalidToken
+	}
+
+	var redemption string
+	if claims.SingleUse {
+		if redemption, err = s.redeem(ctx, claims, resume); err != nil {
+			return nil, err
+		}
+	}
+
+	artifact, err := s.open(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+	artifact.Redemption = redemption
+	artifact.ExpiresAt = time.Unix(claims.ExpiresAt, 0).UTC()
+	if _, ok := artifact.Body.(io.Seeker); !ok {
+		artifact.Body.Close()
+		artifact.Body = nil
+		artifact.RedirectURL, err = s.storage.PresignGet(ctx, job.ObjectKey, time.Minute)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign export link: %w", err)
+		}
+	}
+	return artifact, nil
+}
+
+// redeem records a single-use link as used and returns the new redemption,
+// or checks the request resumes the download of the link's redemption, in
+// which case it returns none
+func (s *exportService) redeem(ctx context.Context, claims download.Claims, resume DownloadResume) (string, error) {
+	if s.redemptions == nil {
+		return "", fmt.Errorf("single-use download links are not configured")
+	}
+
+	// Resuming from the first byte would download the export again
+	if resume.Redemption != "" && resume.Offset > 0 {
+		redemption, err := s.redemptions.Redemption(ctx, claims.LinkID)
+		if err != nil {
+			return "", err
+		}
+		if redemption == "" || subtle.ConstantTimeCompare([]byte(redemption), []byte(resume.Redemption)) != 1 {
+			return "", ErrDownloadLinkUsed
+		}
+		return "", nil
+	}
+
+	redemption := uuid.NewString()
+	redeemed, err := s.redemptions.Redeem(ctx, claims.LinkID, redemption, time.Unix(claims.ExpiresAt, 0))
+	if err != nil {
+		return "", err
+	}
+	if !redeemed {
+		return "", ErrDownloadLinkUsed
+	}
+	return redemption, nil
+}
+
+// completedJob returns the completed job of a form the user can view
+func (s *exportService) completedJob(ctx context.Context, formID, jobID, userID uuid.UUID) (*models.ExportJob, error) {
+	if _, err := s.guard.authorize(ctx, formID, userID, access.View); err != nil {
+		return nil, err
+	}
+	job, err := s.jobs.Get(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.FormID != formID {
+		return nil, repository.ErrExportJobNotFound
+	}
+	if job.Status != models.ExportJobCompleted {
+		return nil, fmt.Errorf("%w: the export is %s", ErrExportNotReady, job.Status)
+	}
+	return job, nil
+}
+
+// open opens the export of a completed job from storage
+func (s *exportService) open(ctx context.Context, job *models.ExportJob) (*ExportArtifact, error) {
+	body, err := s.storage.Open(ctx, job.ObjectKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open export: %w", err)
+	}
+	contentType := job.Format.ContentType()
+	if path.Ext(job.ObjectKey) == ".zip" {
+		contentType = "application/zip"
+	}
+	return &ExportArtifact{Name: "export-" + path.Base(job.ObjectKey), ContentType: contentType, Body: body}, nil
+}
+
+// Cancel cancels the job. Exports can be cancelled by whoever started them
+// and by the collaborators who can edit the form.
+func (s *exportService) Cancel(ctx context.Context, formID, jobID, userID uuid.UUID) (*models.ExportJob, error) {
+	if _, err := s.guard.authorize(ctx, formID, userID, access.View); err != nil {
+		return nil, err
+	}
+	job, err := s.jobs.Get(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.FormID != formID {
+		return nil, repository.ErrExportJobNotFound
+	}
+	if job.RequestedBy != userID {
+		if _, err := s.guard.authorize(ctx, formID, userID, access.Edit); err != nil {
+			return nil, err
+		}
+	}
+
+	job, err = s.jobs.Cancel(ctx, jobID, s.now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Export job %s of form %s cancelled by %s", job.ID, job.FormID, userID)
+	return job, nil
+}
+
+// RunJobs claims the jobs the schedule lets run, running up to
+// MaxConcurrentJobs at a time
+func (s *exportService) RunJobs(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	slots := s.config.MaxConcurrentJobs
+	if slots < 1 {
+		slots = 1
+	}
+	running := make(chan struct{}, slots)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.observeQueue(ctx)
+		claim:
+			for ctx.Err() == nil {
+				select {
+				case running <- struct{}{}:
+				default:
+					break claim
+				}
+				job, err := s.claim(ctx)
+				if err != nil || job == nil {
+					<-running
+					if err != nil {
+						log.Printf("Failed to claim export job: %v", err)
+					}
+					break
+				}
+
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer func() { <-running }()
+					s.runJob(ctx, job)
+				}()
+			}
+		}
+	}
+}
+
+// claim claims the next job the schedule lets run, nil when there is none
+func (s *exportService) claim(ctx context.Context) (*models.ExportJob, error) {
+	now := s.now().UTC()
+	job, err := s.jobs.Claim(ctx, now, now.Add(-exportStaleAfter), repository.ExportSchedule{
+		MaxRunning:                s.config.MaxConcurrentJobs,
+		MaxRunningPerOrganization: s.config.MaxJobsPerOrganization,
+	})
+	if err != nil || job == nil {
+		return nil, err
+	}
+	s.metrics.observeWait(job)
+	return job, nil
+}
+
+// observeQueue exposes the depth of the queue of each organization
+func (s *exportService) observeQueue(ctx context.Context) {
+	if s.metrics == nil {
+		return
+	}
+	depths, err := s.jobs.QueueDepths(ctx)
+	if err != nil {
+		log.Printf("Failed to count queued export jobs: %v", err)
+		return
+	}
+	s.metrics.observeQueue(depths)
+}
+
+// runJob writes the export of a claimed job, streaming it to storage as it
+// goes. An export that fails or is cancelled is not stored. A job
+// interrupted by shutdown is left running, and run again once it went
+// stale.
+func (s *exportService) runJob(ctx context.Context, job *models.ExportJob) {
+	log.Printf("Running export job %s of form %s", job.ID, job.FormID)
+
+	form, err := s.formRepo.GetByID(ctx, job.FormID)
+	if err != nil {
+		s.finish(ctx, job, models.ExportJobFailed, fmt.Sprintf("failed to get form: %v", err))
+		return
+	}
+	questions, err := s.questionRepo.GetByFormID(ctx, form.ID)
+	if err != nil {
+		s.finish(ctx, job, models.ExportJobFailed, fmt.Sprintf("failed to get questions: %v", err))
+		return
+	}
+	sort.SliceStable(questions, func(i, j int) bool { return questions[i].Order < questions[j].Order })
+	keys, err := questionKeys(ctx, s.questionRepo, questions)
+	if err != nil {
+		s.finish(ctx, job, models.ExportJobFailed, err.Error())
+		return
+	}
+	owners := make(map[string]uuid.UUID)
+	for id, questionKeys := range keys {
+		for _, key := range questionKeys {
+			owners[key] = id
+		}
+	}
+
+	key := fmt.Sprintf("exports/forms/%s/%s.%s", job.FormID, job.ID, job.Format)
+	contentType := job.Format.ContentType()
+	if job.IncludeFiles {
+		key = fmt.Sprintf("exports/forms/%s/%s.zip", job.FormID, job.ID)
+		contentType = "application/zip"
+	}
+
+	// The export is piped to storage as it is written; a failure on either
+	// side fails the other
+	pr, pw := io.Pipe()
+	stored := make(chan error, 1)
+	go func() {
+		err := s.storage.PutStream(ctx, key, contentType, pr)
+		pr.CloseWithError(err)
+		stored <- err
+	}()
+
+	e := &export{
+		svc:       s,
+		job:       job,
+		questions: questions,
+		owners:    owners,
+		out:       &exportCounter{w: pw, job: job, limit: s.config.MaxArchiveBytes},
+	}
+	err = e.write(ctx)
+	pw.CloseWithError(err)
+	// Writes fail on the closed pipe when storing failed first
+	if storeErr := <-stored; storeErr != nil && (err == nil || errors.Is(err, io.ErrClosedPipe)) {
+		err = fmt.Errorf("failed to store export: %w", storeErr)
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		return
+	case errors.Is(err, repository.ErrExportJobNotRunning):
+		// Cancelled while running; whatever reached storage is removed
+		if err := s.storage.Delete(ctx, key); err != nil {
+			log.Printf("Export job %s: failed to delete the cancelled export: %v", job.ID, err)
+		}
+		log.Printf("Export job %s stopped after %d responses: cancelled", job.ID, job.Responses)
+	case errors.Is(err, ErrExportTooLarge):
+		s.finish(ctx, job, models.ExportJobFailed, fmt.Sprintf(
+			"the export exceeds the maximum size of %d bytes; export without include_files to leave the files out", s.config.MaxArchiveBytes))
+	case err != nil:
+		s.finish(ctx, job, models.ExportJobFailed, err.Error())
+	default:
+		job.ObjectKey = key
+		s.finish(ctx, job, models.ExportJobCompleted, "")
+	}
+}
+
+// finish records the final status of a job
+func (s *exportService) finish(ctx context.Context, job *models.ExportJob, status models.ExportJobStatus, message string) {
+	finishedAt := s.now().UTC()
+	job.Status = status
+	job.Error = message
+	job.FinishedAt = &finishedAt
+	if err := s.jobs.Finish(ctx, job); err != nil {
+		log.Printf("Export job %s: failed to record its end: %v", job.ID, err)
+		return
+	}
+	log.Printf("Export job %s %s: %d responses, %d files, %d skipped, %d bytes", job.ID, status, job.Responses, job.FilesWritten, job.FilesSkipped, job.BytesWritten)
+}
+
+// saveProgress saves the counts of the job, which goes on when they can't
+// be saved. It fails with repository.ErrExportJobNotRunning once the job
+// was cancelled, which stops the export at this batch.
+func (s *exportService) saveProgress(ctx context.Context, job *models.ExportJob) error {
+	err := s.jobs.SaveProgress(ctx, job)
+	if errors.Is(err, repository.ErrExportJobNotRunning) {
+		return err
+	}
+	if err != nil {
+		log.Printf("Export job %s: failed to save progress: %v", job.ID, err)
+	}
+	return nil
+}
+
+// exportCounter counts the bytes of the export in its job, failing with
+// ErrExportTooLarge past the limit
+type exportCounter struct {
+	w     io.Writer
+	job   *models.ExportJob
+	limit int64
+}
+
+func (c *exportCounter) Write(p []byte) (int, error) {
+	if c.limit > 0 && c.job.BytesWritten+int64(len(p)) > c.limit {
+		return 0, ErrExportTooLarge
+	}
+	n, err := c.w.Write(p)
+	c.job.BytesWritten += int64(n)
+	return n, err
+}
+
+// export writes the export of a job
+type export struct {
+	svc       *exportService
+	job       *models.ExportJob
+	questions []*models.Question
+	// owners are the questions by their keys, current and former
+	owners map[string]uuid.UUID
+	out    io.Writer
+}
+
+// write writes the responses file alone, or with IncludeFiles an archive
+// of the responses file followed by a folder of files for each response
+func (e *export) write(ctx context.Context) error {
+	if !e.job.IncludeFiles {
+		return e.writeResponses(ctx, e.out)
+	}
+
+	archive := zip.NewWriter(e.out)
+	f, err := archive.Create("responses." + string(e.job.Format))
+	if err != nil {
+		return err
+	}
+	if err := e.writeResponses(ctx, f); err != nil {
+		return err
+	}
+	if err := e.writeFiles(ctx, archive); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+// writeResponses writes a row for each response, a column for each
+// question named by its key, and with IncludeLabels a second header row of
+// question titles. File questions list the files answering them, by their
+// path in the archive with IncludeFiles. With IncludePresentedOrder,
+// questions with randomized options are followed by the order their options
+// were shown in, and with IncludeTimings every question by its timing. With
+// IncludeTest, a last column flags the test submissions.
+func (e *export) writeResponses(ctx context.Context, w io.Writer) error {
+	sheet, err := newSheetWriter(w, e.job.Format)
+	if err != nil {
+		return err
+	}
+	presented := e.presentedOrderQuestions()
+	header := []string{"response_id", "submitted_at", "respondent_id"}
+	labels := []string{"Response ID", "Submitted at", "Respondent ID"}
+	for _, question := range e.questions {
+		name := question.Key
+		if name == "" {
+			name = question.Title
+		}
+		header = append(header, name)
+		labels = append(labels, question.Title)
+		if presented[question.ID] {
+			header = append(header, name+" (presented order)")
+			labels = append(labels, question.Title+" (presented order)")
+		}
+		if e.job.IncludeTimings {
+			header = append(header, name+" (seconds)", name+" (revisits)")
+			labels = append(labels, question.Title+" (seconds)", question.Title+" (revisits)")
+		}
+	}
+	if e.job.IncludeTest {
+		header = append(header, "test")
+		labels = append(labels, "Test")
+	}
+	if err := sheet.WriteRow(header); err != nil {
+		return err
+	}
+	if e.job.IncludeLabels {
+		if err := sheet.WriteRow(labels); err != nil {
+			return err
+		}
+	}
+
+	err = e.eachPage(ctx, func(responses []analytics.Response, uploads map[string][]*models.FileUpload) error {
+		timings, err := e.timings(ctx, responses)
+		if err != nil {
+			return err
+		}
+		for _, response := range responses {
+			names := e.fileNames(response.ID, uploads[response.ID])
+			row := []string{response.ID, response.SubmittedAt.UTC().Format(time.RFC3339), response.RespondentID}
+			for _, question := range e.questions {
+				if question.Type == models.QuestionTypeFile {
+					row = append(row, strings.Join(names[question.ID], "; "))
+				} else {
+					row = append(row, exportCell(e.answer(response, question.ID)))
+				}
+				if presented[question.ID] {
+					row = append(row, strings.Join(response.PresentedOrder[question.ID.String()], "; "))
+				}
+				if e.job.IncludeTimings {
+					row = append(row, timingCells(timings[response.ID], question.ID.String())...)
+				}
+			}
+			if e.job.IncludeTest {
+				row = append(row, strconv.FormatBool(response.Test))
+			}
+			if err := sheet.WriteRow(row); err != nil {
+				return err
+			}
+			e.job.Responses++
+		}
+		if err := sheet.Flush(); err != nil {
+			return err
+		}
+		return e.svc.saveProgress(ctx, e.job)
+	})
+	if err != nil {
+		return err
+	}
+	return sheet.Close()
+}
+
+// answer returns the answer of a response to a question, or when the
+// response didn't answer the question itself, its answer to a deleted
+// question under a key of the question
+func (e *export) answer(response analytics.Response, questionID uuid.UUID) json.RawMessage {
+	if answer, ok := response.Answers[questionID.String()]; ok {
+		return answer
+	}
+	for answered, key := range response.Keys {
+		if owner, ok := e.owners[key]; ok && owner == questionID {
+			return response.Answers[answered]
+		}
+	}
+	return nil
+}
+
+// timings reads the timings of a page of responses, when the job includes
+// them
+func (e *export) timings(ctx context.Context, responses []analytics.Response) (map[string]map[string]analytics.QuestionTiming, error) {
+	if !e.job.IncludeTimings {
+		return nil, nil
+	}
+	ids := make([]string, len(responses))
+	for i, response := range responses {
+		ids[i] = response.ID
+	}
+	timings, err := e.svc.responses.ResponseTimings(ctx, e.job.FormID.String(), ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read timings: %w", err)
+	}
+	return timings, nil
+}
+
+// timingCells returns the seconds a question held focus and its revisits,
+// empty when the response didn't time it
+func timingCells(timings map[string]analytics.QuestionTiming, questionID string) []string {
+	timing, ok := timings[questionID]
+	if !ok {
+		return []string{"", ""}
+	}
+	return []string{
+		strconv.FormatFloat(float64(timing.FocusMs)/1000, 'f', 1, 64),
+		strconv.Itoa(timing.Revisits),
+	}
+}
+
+// presentedOrderQuestions returns the questions with randomized options,
+// when the job includes their presented order
+func (e *export) presentedOrderQuestions() map[uuid.UUID]bool {
+	presented := make(map[uuid.UUID]bool)
+	if !e.job.IncludePresentedOrder {
+		return presented
+	}
+	for _, question := range e.questions {
+		if display, err := question.GetDisplay(); err == nil && display.RandomizeOptions {
+			presented[question.ID] = true
+		}
+	}
+	return presented
+}
+
+// writeFiles copies the files of each response into its folder. Files that
+// are missing from storage or were quarantined are replaced by a text file
+// giving the reason.
+func (e *export) writeFiles(ctx context.Context, archive *zip.Writer) error {
+	return e.eachPage(ctx, func(responses []analytics.Response, uploads map[string][]*models.FileUpload) error {
+		for _, response := range responses {
+			names := e.archiveNames(response.ID, uploads[response.ID])
+			for _, upload := range uploads[response.ID] {
+				if err := e.writeFile(ctx, archive, upload, names[upload.ID]); err != nil {
+					return err
+				}
+			}
+		}
+		return e.svc.saveProgress(ctx, e.job)
+	})
+}
+
+// writeFile copies an upload into the archive as name, or its placeholder
+func (e *export) writeFile(ctx context.Context, archive *zip.Writer, upload *models.FileUpload, name string) error {
+	if upload.Quarantined || upload.ScanStatus == models.FileScanStatusInfected {
+		reason := "it was quarantined by the antivirus scan"
+		if upload.ScanSignature != "" {
+			reason += " (" + upload.ScanSignature + ")"
+		}
+		return e.writePlaceholder(archive, upload, name, reason)
+	}
+
+	body, err := e.svc.storage.Open(ctx, upload.ObjectKey)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return e.writePlaceholder(archive, upload, name, "it is missing from storage")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open file %s: %w", upload.ID, err)
+	}
+	defer body.Close()
+
+	f, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, body); err != nil {
+		return fmt.Errorf("failed to copy file %s: %w", upload.ID, err)
+	}
+	e.job.FilesWritten++
+	return nil
+}
+
+// writePlaceholder writes name.txt in place of an upload left out
+func (e *export) writePlaceholder(archive *zip.Writer, upload *models.FileUpload, name, reason string) error {
+	f, err := archive.Create(name + ".txt")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(f, "The file %q (upload %s) is not included in this export: %s.\n", upload.FileName, upload.ID, reason)
+	if err != nil {
+		return err
+	}
+	e.job.FilesSkipped++
+	return nil
+}
+
+// eachPage calls fn with each page of responses and the files attached to
+// them, by response
+func (e *export) eachPage(ctx context.Context, fn func(responses []analytics.Response, uploads map[string][]*models.FileUpload) error) error {
+	var after analytics.ResponseCursor
+	for {
+		responses, err := e.svc.responses.Responses(ctx, e.job.FormID.String(), after, exportBatchSize, e.job.IncludeTest)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]string, len(responses))
+		for i, response := range responses {
+			ids[i] = response.ID
+		}
+		attached, err := e.svc.uploads.ListAttached(ctx, e.job.FormID, ids)
+		if err != nil {
+			return fmt.Errorf("failed to list files: %w", err)
+		}
+		uploads := make(map[string][]*models.FileUpload)
+		for _, upload := range attached {
+			uploads[upload.ResponseID] = append(uploads[upload.ResponseID], upload)
+		}
+
+		if err := fn(responses, uploads); err != nil {
+			return err
+		}
+		if len(responses) < exportBatchSize {
+			return nil
+		}
+		last := responses[len(responses)-1]
+		after = analytics.ResponseCursor{SubmittedAt: last.SubmittedAt, ID: last.ID}
+	}
+}
+
+// fileNames lists the files of a response by question: their path in the
+// archive with IncludeFiles, their name otherwise
+func (e *export) fileNames(responseID string, uploads []*models.FileUpload) map[uuid.UUID][]string {
+	var paths map[uuid.UUID]string
+	if e.job.IncludeFiles {
+		paths = e.archiveNames(responseID, uploads)
+	}
+	names := make(map[uuid.UUID][]string)
+	for _, upload := range uploads {
+		name := upload.FileName
+		if paths != nil {
+			name = paths[upload.ID]
+		}
+		names[upload.QuestionID] = append(names[upload.QuestionID], name)
+	}
+	return names
+}
+
+// archiveNames names the files of a response in the archive: the folder of
+// the response, then the file name prefixed by the key of its question.
+// Names taken by an earlier file are told apart by the upload ID.
+func (e *export) archiveNames(responseID string, uploads []*models.FileUpload) map[uuid.UUID]string {
+	folder := archiveSegment(responseID, "response")
+	names := make(map[uuid.UUID]string, len(uploads))
+	taken := make(map[string]bool, len(uploads))
+	for _, upload := range uploads {
+		base := e.questionKey(upload.QuestionID) + "_" + archiveSegment(upload.FileName, "file")
+		name := path.Join(folder, base)
+		if taken[name] {
+			name = path.Join(folder, e.questionKey(upload.QuestionID)+"_"+upload.ID.String()+"_"+archiveSegment(upload.FileName, "file"))
+		}
+		taken[name] = true
+		names[upload.ID] = name
+	}
+	return names
+}
+
+// questionKey is the key of a question in the names of the files answering
+// it: q and its position in the form. Files of deleted questions keep the
+// question ID.
+func (e *export) questionKey(id uuid.UUID) string {
+	for _, question := range e.questions {
+		if question.ID == id {
+			return fmt.Sprintf("q%d", question.Order)
+		}
+	}
+	return id.String()
+}
+
+// archiveSegment makes name safe as a single segment of a path in the
+// archive, or returns fallback for names left empty
+func archiveSegment(name, fallback string) string {
+	name = strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r < 0x20 {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" || name == "." || name == ".." {
+		return fallback
+	}
+	return name
+}
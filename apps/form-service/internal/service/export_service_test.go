@@ -0,0 +1,813 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/analytics"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/download"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+	"github.com/Mir00r/X-Form-Backend/shared/storage"
+)
+
+// memoryExportJobRepository keeps export jobs in memory. saved records the
+// progress of each save.
+type memoryExportJobRepository struct {
+	mu    sync.Mutex
+	jobs  map[uuid.UUID]models.ExportJob
+	saved []models.ExportJob
+}
+
+func (r *memoryExportJobRepository) Create(_ context.Context, job *models.ExportJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job.ID = uuid.New()
+	job.CreatedAt = time.Now()
+	r.jobs[job.ID] = *job
+	return nil
+}
+
+func (r *memoryExportJobRepository) Get(_ context.Context, id uuid.UUID) (*models.ExportJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, repository.ErrExportJobNotFound
+	}
+	return &job, nil
+}
+
+// Claim picks the job to run like the database does, by NextExportJob
+func (r *memoryExportJobRepository) Claim(_ context.Context, now, staleBefore time.Time, schedule repository.ExportSchedule) (*models.ExportJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	running := make(map[uuid.UUID]int)
+	lastStarted := make(map[uuid.UUID]time.Time)
+	oldest := make(map[string]*models.ExportJob)
+	for _, job := range r.jobs {
+		job := job
+		if job.StartedAt != nil && job.StartedAt.After(lastStarted[job.OrganizationID]) {
+			lastStarted[job.OrganizationID] = *job.StartedAt
+		}
+		stale := job.Status == models.ExportJobRunning && job.UpdatedAt.Before(staleBefore)
+		if job.Status == models.ExportJobRunning && !stale {
+			running[job.OrganizationID]++
+		}
+		if job.Status != models.ExportJobQueued && !stale {
+			continue
+		}
+		key := job.OrganizationID.String() + "/" + string(job.Priority)
+		if first, ok := oldest[key]; !ok || job.CreatedAt.Before(first.CreatedAt) {
+			oldest[key] = &job
+		}
+	}
+	var candidates []*models.ExportJob
+	for _, job := range oldest {
+		candidates = append(candidates, job)
+	}
+
+	job := repository.NextExportJob(candidates, running, lastStarted, schedule)
+	if job == nil {
+		return nil, nil
+	}
+	job.Status, job.StartedAt, job.UpdatedAt = models.ExportJobRunning, &now, now
+	job.Responses, job.BytesWritten, job.FilesWritten, job.FilesSkipped = 0, 0, 0, 0
+	r.jobs[job.ID] = *job
+	return job, nil
+}
+
+func (r *memoryExportJobRepository) QueuePosition(_ context.Context, job *models.ExportJob) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	position := 1
+	for _, other := range r.jobs {
+		if other.ID == job.ID || other.OrganizationID != job.OrganizationID || other.Status != models.ExportJobQueued {
+			continue
+		}
+		if other.Priority == job.Priority && other.CreatedAt.Before(job.CreatedAt) ||
+			other.Priority != job.Priority && job.Priority == models.ExportPriorityLow {
+			position++
+		}
+	}
+	return position, nil
+}
+
+func (r *memoryExportJobRepository) QueueDepths(context.Context) (map[uuid.UUID]int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	depths := make(map[uuid.UUID]int)
+	for _, job := range r.jobs {
+		if job.Status == models.ExportJobQueued {
+			depths[job.OrganizationID]++
+		}
+	}
+	return depths, nil
+}
+
+func (r *memoryExportJobRepository) SaveProgress(_ context.Context, job *models.ExportJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.saved = append(r.saved, *job)
+	if r.jobs[job.ID].Status != models.ExportJobRunning {
+		return repository.ErrExportJobNotRunning
+	}
+	return nil
+}
+
+func (r *memoryExportJobRepository) Cancel(_ context.Context, id uuid.UUID, now time.Time) (*models.ExportJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, repository.ErrExportJobNotFound
+	}
+	if job.Status.IsFinished() {
+		return nil, repository.ErrExportJobFinished
+	}
+	job.Status, job.FinishedAt = models.ExportJobCancelled, &now
+	r.jobs[id] = job
+	return &job, nil
+}
+
+func (r *memoryExportJobRepository) Finish(_ context.Context, job *models.ExportJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.jobs[job.ID].Status != models.ExportJobRunning {
+		return nil
+	}
+	r.jobs[job.ID] = *job
+	return nil
+}
+
+// memoryRedemptions records the redemption of the links redeemed
+type memoryRedemptions map[uuid.UUID]string
+
+func (r memoryRedemptions) Redeem(_ context.Context, linkID uuid.UUID, redemption string, _ time.Time) (bool, error) {
+	if r[linkID] != "" {
+		return false, nil
+	}
+	r[linkID] = redemption
+	return true, nil
+}
+
+func (r memoryRedemptions) Redemption(_ context.Context, linkID uuid.UUID) (string, error) {
+	return r[linkID], nil
+}
+
+type exportFixture struct {
+	*memoryStore
+	svc       *exportService
+	jobs      *memoryExportJobRepository
+	responses *memoryResponses
+	uploads   *memoryFileUploads
+	store     storage.Backend
+	form      *models.Form
+	owner     uuid.UUID
+	name      *models.Question
+	cv        *models.Question
+}
+
+// newExportFixture creates a form asking for a name and a CV, answered by
+// count responses
+func newExportFixture(t *testing.T, count int) *exportFixture {
+	t.Helper()
+	store, err := storage.NewLocalStorage(t.TempDir(), "http://localhost:8001", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &exportFixture{
+		memoryStore: newMemoryStore(time.Now()),
+		jobs:        &memoryExportJobRepository{jobs: make(map[uuid.UUID]models.ExportJob)},
+		responses:   &memoryResponses{},
+		uploads:     &memoryFileUploads{},
+		store:       store,
+		owner:       uuid.New(),
+	}
+	f.form = f.createForm(t, &models.Form{UserID: f.owner, Title: "Applications", Status: models.FormStatusPublished})
+	f.name = &models.Question{FormID: f.form.ID, Type: models.QuestionTypeText, Title: "Name", Order: 1}
+	f.cv = &models.Question{FormID: f.form.ID, Type: models.QuestionTypeFile, Title: "CV", Order: 2}
+	f.createQuestions(t, f.cv, f.name)
+
+	submitted := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < count; i++ {
+		name, _ := json.Marshal(fmt.Sprintf("Applicant %d", i))
+		f.responses.responses = append(f.responses.responses, analytics.Response{
+			ID:          fmt.Sprintf("resp-%03d", i),
+			SubmittedAt: submitted.Add(time.Duration(i) * time.Minute),
+			Answers:     map[string]json.RawMessage{f.name.ID.String(): name},
+		})
+	}
+
+	f.svc = NewExportService(f.forms, f.questions, nil, f.orgs, f.jobs, f.responses, f.uploads, store, ExportConfig{
+		MaxArchiveBytes: 10 << 20,
+		LinkTTL:         time.Hour,
+		SigningSecret:   "secret",
+	}, nil, nil, memoryRedemptions{}).(*exportService)
+	return f
+}
+
+// attach stores body as a file answering the CV question of a response;
+// nil bodies are never stored
+func (f *exportFixture) attach(t *testing.T, responseID, fileName string, body []byte) *models.FileUpload {
+	t.Helper()
+	upload := &models.FileUpload{
+		ID:         uuid.New(),
+		FormID:     f.form.ID,
+		QuestionID: f.cv.ID,
+		ObjectKey:  fmt.Sprintf("uploads/%s/%s", f.form.ID, uuid.New()),
+		FileName:   fileName,
+		Status:     models.FileUploadStatusAttached,
+		ResponseID: responseID,
+		ScanStatus: models.FileScanStatusClean,
+	}
+	if body != nil {
+		if err := f.store.Put(context.Background(), upload.ObjectKey, "application/pdf", body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.uploads.uploads = append(f.uploads.uploads, upload)
+	return upload
+}
+
+// run starts an export and runs it to its end
+func (f *exportFixture) run(t *testing.T, req ExportRequest) *models.ExportJob {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := f.svc.Start(ctx, f.form.ID, f.owner, req); err != nil {
+		t.Fatal(err)
+	}
+	job, err := f.svc.claim(ctx)
+	if err != nil || job == nil {
+		t.Fatalf("Claim = %v, %v; want the queued job", job, err)
+	}
+	f.svc.runJob(ctx, job)
+	job, err = f.svc.GetJob(ctx, f.form.ID, job.ID, f.owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return job
+}
+
+// read returns the stored export
+func (f *exportFixture) read(t *testing.T, job *models.ExportJob) []byte {
+	t.Helper()
+	body, err := f.store.Open(context.Background(), job.ObjectKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// zipEntries returns the contents of the entries of an archive by name
+func zipEntries(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := make(map[string]string)
+	for _, file := range archive.File {
+		r, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[file.Name] = string(content)
+	}
+	return entries
+}
+
+func TestExportBundlesFiles(t *testing.T) {
+	f := newExportFixture(t, 150)
+	f.attach(t, "resp-000", "cv.pdf", []byte("%PDF resume"))
+	f.attach(t, "resp-000", "cv.pdf", []byte("%PDF cover letter"))
+	infected := f.attach(t, "resp-001", "invoice.pdf", []byte("X5O!P%@AP"))
+	infected.ScanStatus, infected.ScanSignature, infected.Quarantined = models.FileScanStatusInfected, "Eicar-Test-Signature", true
+	missing := f.attach(t, "resp-149", "lost.pdf", nil)
+
+	job := f.run(t, ExportRequest{Format: models.ExportFormatCSV, IncludeFiles: true})
+	if job.Status != models.ExportJobCompleted || job.Error != "" {
+		t.Fatalf("status = %s (%s), want completed", job.Status, job.Error)
+	}
+	if job.Responses != 150 || job.FilesWritten != 2 || job.FilesSkipped != 2 {
+		t.Errorf("progress = %d responses, %d files, %d skipped; want 150, 2, 2", job.Responses, job.FilesWritten, job.FilesSkipped)
+	}
+	data := f.read(t, job)
+	if job.BytesWritten != int64(len(data)) {
+		t.Errorf("bytes written = %d, want the %d bytes of the archive", job.BytesWritten, len(data))
+	}
+	if want := fmt.Sprintf("/api/v1/forms/%s/exports/%s/download", f.form.ID, job.ID); job.DownloadURL != want {
+		t.Errorf("download URL = %q, want %q", job.DownloadURL, want)
+	}
+	// Progress is saved after each page of responses, then of files
+	if len(f.jobs.saved) != 4 || f.jobs.saved[0].Responses != 100 {
+		t.Errorf("%d progress saves, want 4 beginning after 100 responses", len(f.jobs.saved))
+	}
+
+	entries := zipEntries(t, data)
+	if entries["resp-000/q2_cv.pdf"] != "%PDF resume" {
+		t.Errorf("first file = %q, want the resume", entries["resp-000/q2_cv.pdf"])
+	}
+	second := fmt.Sprintf("resp-000/q2_%s_cv.pdf", f.uploads.uploads[1].ID)
+	if entries[second] != "%PDF cover letter" {
+		t.Errorf("file of the same name = %q, want the cover letter under its upload ID", entries[second])
+	}
+	if placeholder := entries["resp-001/q2_invoice.pdf.txt"]; !strings.Contains(placeholder, "quarantined") || !strings.Contains(placeholder, "Eicar-Test-Signature") {
+		t.Errorf("placeholder of the infected file = %q", placeholder)
+	}
+	if _, ok := entries["resp-001/q2_invoice.pdf"]; ok {
+		t.Error("infected file archived")
+	}
+	if placeholder := entries["resp-149/q2_lost.pdf.txt"]; !strings.Contains(placeholder, "missing") || !strings.Contains(placeholder, missing.ID.String()) {
+		t.Errorf("placeholder of the missing file = %q", placeholder)
+	}
+
+	rows, err := csv.NewReader(strings.NewReader(entries["responses.csv"])).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 151 {
+		t.Fatalf("%d rows, want a header and 150 responses", len(rows))
+	}
+	if want := []string{"response_id", "submitted_at", "respondent_id", "Name", "CV"}; strings.Join(rows[0], ",") != strings.Join(want, ",") {
+		t.Errorf("header = %v, want %v", rows[0], want)
+	}
+	if want := "resp-000/q2_cv.pdf; " + second; rows[1][3] != "Applicant 0" || rows[1][4] != want {
+		t.Errorf("first response = %v, want its name and the paths of its files", rows[1])
+	}
+}
+
+func TestExportFailsPastMaxSize(t *testing.T) {
+	f := newExportFixture(t, 3)
+	f.svc.config.MaxArchiveBytes = 64 << 10
+	// Random bytes, which don't compress
+	video := make([]byte, 100<<10)
+	rand.New(rand.NewSource(1)).Read(video)
+	f.attach(t, "resp-000", "video.mp4", video)
+
+	job := f.run(t, ExportRequest{Format: models.ExportFormatCSV, IncludeFiles: true})
+	if job.Status != models.ExportJobFailed || !strings.Contains(job.Error, "maximum size of 65536 bytes") {
+		t.Fatalf("status = %s (%s), want failed past the maximum size", job.Status, job.Error)
+	}
+	if job.DownloadURL != "" {
+		t.Error("failed export with a download link")
+	}
+	if _, err := f.store.Stat(context.Background(), fmt.Sprintf("exports/forms/%s/%s.zip", f.form.ID, job.ID)); !errors.Is(err, storage.ErrObjectNotFound) {
+		t.Errorf("stat of the failed export: err = %v, want ErrObjectNotFound", err)
+	}
+
+	// Without the files the export fits
+	job = f.run(t, ExportRequest{Format: models.ExportFormatCSV})
+	if job.Status != models.ExportJobCompleted || job.FilesWritten != 0 {
+		t.Errorf("status = %s (%s) with %d files, want completed without files", job.Status, job.Error, job.FilesWritten)
+	}
+	if rows := strings.Count(string(f.read(t, job)), "\n"); rows != 4 {
+		t.Errorf("%d lines, want a header and 3 responses", rows)
+	}
+}
+
+func TestExportXLSX(t *testing.T) {
+	f := newExportFixture(t, 2)
+	f.attach(t, "resp-001", "cv.pdf", []byte("%PDF"))
+
+	job := f.run(t, ExportRequest{Format: models.ExportFormatXLSX})
+	if job.Status != models.ExportJobCompleted {
+		t.Fatalf("status = %s (%s), want completed", job.Status, job.Error)
+	}
+	sheet := zipEntries(t, f.read(t, job))["xl/worksheets/sheet1.xml"]
+	for _, want := range []string{`<c r="D1" t="inlineStr"><is><t xml:space="preserve">Name</t>`, "Applicant 1", ">cv.pdf<", `<row r="3">`} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("sheet lacks %s:\n%s", want, sheet)
+		}
+	}
+}
+
+func TestExportAccess(t *testing.T) {
+	ctx := context.Background()
+	f := newExportFixture(t, 1)
+
+	if _, err := f.svc.Start(ctx, f.form.ID, uuid.New(), ExportRequest{}); !errors.Is(err, ErrNotFormOwner) {
+		t.Errorf("export by a stranger: err = %v, want ErrNotFormOwner", err)
+	}
+	if _, err := f.svc.Start(ctx, f.form.ID, f.owner, ExportRequest{Format: "pdf"}); !errors.Is(err, ErrExportInvalid) {
+		t.Errorf("pdf export: err = %v, want ErrExportInvalid", err)
+	}
+	job, err := f.svc.Start(ctx, f.form.ID, f.owner, ExportRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Format != models.ExportFormatCSV || job.Status != models.ExportJobQueued {
+		t.Errorf("job = %s %s, want a queued csv export", job.Format, job.Status)
+	}
+	if _, err := f.svc.GetJob(ctx, uuid.New(), job.ID, f.owner); !errors.Is(err, ErrFormNotFound) {
+		t.Errorf("job of another form: err = %v, want ErrFormNotFound", err)
+	}
+
+	f.svc.responses = nil
+	if _, err := f.svc.Start(ctx, f.form.ID, f.owner, ExportRequest{}); !errors.Is(err, analytics.ErrNotConfigured) {
+		t.Errorf("export without the response projection: err = %v, want ErrNotConfigured", err)
+	}
+}
+
+func TestXLSXColumn(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if got := xlsxColumn(i); got != want {
+			t.Errorf("xlsxColumn(%d) = %s, want %s", i, got, want)
+		}
+	}
+}
+
+// queue queues a job of the organization directly in the repository, a
+// second after the previous one
+func (f *exportFixture) queue(orgID uuid.UUID, priority models.ExportPriority) *models.ExportJob {
+	f.jobs.mu.Lock()
+	defer f.jobs.mu.Unlock()
+	job := models.ExportJob{ID: uuid.New(), FormID: f.form.ID, OrganizationID: orgID, RequestedBy: f.owner,
+		Format: models.ExportFormatCSV, Priority: priority, Status: models.ExportJobQueued,
+		CreatedAt: time.Date(2024, 5, 1, 9, 0, len(f.jobs.jobs), 0, time.UTC)}
+	f.jobs.jobs[job.ID] = job
+	return &job
+}
+
+func TestExportSchedulingIsFair(t *testing.T) {
+	ctx := context.Background()
+	f := newExportFixture(t, 1)
+	f.svc.config.MaxConcurrentJobs, f.svc.config.MaxJobsPerOrganization = 4, 2
+	large, small := uuid.New(), uuid.New()
+	for i := 0; i < 50; i++ {
+		f.queue(large, models.ExportPriorityNormal)
+	}
+	smallJob := f.queue(small, models.ExportPriorityNormal)
+
+	// The organization with 50 exports gets one, then the other
+	// organization gets its turn, and the first its second
+	var claimed []uuid.UUID
+	for {
+		job, err := f.svc.claim(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job == nil {
+			break
+		}
+		claimed = append(claimed, job.OrganizationID)
+	}
+	if want := []uuid.UUID{large, small, large}; fmt.Sprint(claimed) != fmt.Sprint(want) {
+		t.Fatalf("claimed jobs of %v, want %v", claimed, want)
+	}
+
+	// The other jobs wait their turn, their place in the queue shown
+	jobs, _ := f.jobs.QueueDepths(ctx)
+	if jobs[large] != 48 {
+		t.Errorf("queue depth = %d, want 48", jobs[large])
+	}
+	var last *models.ExportJob
+	for _, job := range f.jobs.jobs {
+		job := job
+		if job.Status == models.ExportJobQueued && (last == nil || job.CreatedAt.After(last.CreatedAt)) {
+			last = &job
+		}
+	}
+	got, err := f.svc.GetJob(ctx, f.form.ID, last.ID, f.owner)
+	if err != nil || got.QueuePosition != 48 {
+		t.Errorf("queue position = %d, %v; want 48", got.QueuePosition, err)
+	}
+	if got, _ := f.svc.GetJob(ctx, f.form.ID, smallJob.ID, f.owner); got.QueuePosition != 0 {
+		t.Errorf("running job with queue position %d", got.QueuePosition)
+	}
+}
+
+func TestExportSchedulingLimits(t *testing.T) {
+	ctx := context.Background()
+	f := newExportFixture(t, 1)
+	f.svc.config.MaxConcurrentJobs, f.svc.config.MaxJobsPerOrganization = 2, 2
+	a, b := uuid.New(), uuid.New()
+	bulk := f.queue(a, models.ExportPriorityLow)
+	f.queue(a, models.ExportPriorityNormal)
+	f.queue(b, models.ExportPriorityNormal)
+	f.queue(b, models.ExportPriorityNormal)
+
+	// Normal jobs run first, within the global limit
+	for i := 0; i < 2; i++ {
+		job, err := f.svc.claim(ctx)
+		if err != nil || job == nil || job.Priority != models.ExportPriorityNormal {
+			t.Fatalf("claim %d = %+v, %v; want a normal job", i, job, err)
+		}
+		defer func(job *models.ExportJob) { f.svc.finish(ctx, job, models.ExportJobCompleted, "") }(job)
+	}
+	if job, _ := f.svc.claim(ctx); job != nil {
+		t.Fatalf("claimed %s past the global limit", job.ID)
+	}
+	if got, _ := f.svc.GetJob(ctx, f.form.ID, bulk.ID, f.owner); got.QueuePosition != 1 {
+		t.Errorf("low priority queue position = %d, want 1 once the normal job of its organization runs", got.QueuePosition)
+	}
+}
+
+func TestExportCancel(t *testing.T) {
+	ctx := context.Background()
+	f := newExportFixture(t, 250)
+	stranger := uuid.New()
+
+	queued, err := f.svc.Start(ctx, f.form.ID, f.owner, ExportRequest{Priority: models.ExportPriorityLow})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.svc.Cancel(ctx, f.form.ID, queued.ID, stranger); !errors.Is(err, ErrNotFormOwner) {
+		t.Errorf("cancel by a stranger: err = %v, want ErrNotFormOwner", err)
+	}
+	if job, err := f.svc.Cancel(ctx, f.form.ID, queued.ID, f.owner); err != nil || job.Status != models.ExportJobCancelled {
+		t.Fatalf("cancel of a queued job = %+v, %v", job, err)
+	}
+	if _, err := f.svc.Cancel(ctx, f.form.ID, queued.ID, f.owner); !errors.Is(err, repository.ErrExportJobFinished) {
+		t.Errorf("second cancel: err = %v, want ErrExportJobFinished", err)
+	}
+	if _, err := f.svc.Start(ctx, f.form.ID, f.owner, ExportRequest{Priority: "urgent"}); !errors.Is(err, ErrExportInvalid) {
+		t.Errorf("urgent export: err = %v, want ErrExportInvalid", err)
+	}
+
+	// A running job stops at the batch after it is cancelled
+	if _, err := f.svc.Start(ctx, f.form.ID, f.owner, ExportRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	job, err := f.svc.claim(ctx)
+	if err != nil || job == nil {
+		t.Fatalf("claim = %v, %v", job, err)
+	}
+	f.svc.jobs = &cancellingJobs{memoryExportJobRepository: f.jobs, svc: f.svc, form: f.form.ID, owner: f.owner}
+	f.svc.runJob(ctx, job)
+
+	got, err := f.svc.GetJob(ctx, f.form.ID, job.ID, f.owner)
+	if err != nil || got.Status != models.ExportJobCancelled || got.DownloadURL != "" {
+		t.Fatalf("job = %+v, %v; want cancelled without a link", got, err)
+	}
+	if saved := f.jobs.saved[len(f.jobs.saved)-1]; saved.Responses != 100 {
+		t.Errorf("stopped after %d responses, want the first batch of 100", saved.Responses)
+	}
+	if _, err := f.store.Stat(ctx, fmt.Sprintf("exports/forms/%s/%s.csv", f.form.ID, job.ID)); !errors.Is(err, storage.ErrObjectNotFound) {
+		t.Errorf("stat of the cancelled export: err = %v, want ErrObjectNotFound", err)
+	}
+}
+
+// cancellingJobs cancels the running job when its first batch is saved
+type cancellingJobs struct {
+	*memoryExportJobRepository
+	svc         *exportService
+	form, owner uuid.UUID
+}
+
+func (r *cancellingJobs) SaveProgress(ctx context.Context, job *models.ExportJob) error {
+	if _, err := r.svc.Cancel(ctx, r.form, job.ID, r.owner); err != nil && !errors.Is(err, repository.ErrExportJobFinished) {
+		return err
+	}
+	return r.memoryExportJobRepository.SaveProgress(ctx, job)
+}
+
+func TestExportDownloadLinks(t *testing.T) {
+	ctx := context.Background()
+	f := newExportFixture(t, 3)
+	now := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	f.svc.now = func() time.Time { return now }
+
+	job, err := f.svc.Start(ctx, f.form.ID, f.owner, ExportRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.svc.DownloadLink(ctx, f.form.ID, job.ID, f.owner); !errors.Is(err, ErrExportNotReady) {
+		t.Errorf("link to a queued export: err = %v, want ErrExportNotReady", err)
+	}
+	job, err = f.svc.claim(ctx)
+	if err != nil || job == nil {
+		t.Fatalf("claim = %v, %v", job, err)
+	}
+	f.svc.runJob(ctx, job)
+	if _, err := f.svc.DownloadLink(ctx, f.form.ID, job.ID, uuid.New()); !errors.Is(err, ErrNotFormOwner) {
+		t.Errorf("link for a stranger: err = %v, want ErrNotFormOwner", err)
+	}
+
+	// Reusable links are presigned by storage
+	link, err := f.svc.DownloadLink(ctx, f.form.ID, job.ID, f.owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(link.URL, "http://localhost:8001/") || link.SingleUse || !link.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("link = %+v, want a storage link expiring in an hour", link)
+	}
+
+	f.svc.config.SingleUseLinks = true
+	link, err = f.svc.DownloadLink(ctx, f.form.ID, job.ID, f.owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := url.Parse(link.URL)
+	if err != nil || parsed.Path != fmt.Sprintf("/api/v1/public/forms/%s/exports/%s/download", f.form.ID, job.ID) || !link.SingleUse {
+		t.Fatalf("link = %+v, want a single-use link to the public download endpoint", link)
+	}
+	token := parsed.Query().Get("token")
+
+	if _, err := f.svc.RedeemDownload(ctx, f.form.ID, uuid.New(), token, DownloadResume{}); !errors.Is(err, download.ErrInvalidToken) {
+		t.Errorf("token used for another export: err = %v, want ErrInvalidToken", err)
+	}
+	artifact, err := f.svc.RedeemDownload(ctx, f.form.ID, job.ID, token, DownloadResume{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(artifact.Body)
+	artifact.Body.Close()
+	if err != nil || string(data) != string(f.read(t, job)) || !strings.HasPrefix(artifact.ContentType, "text/csv") {
+		t.Errorf("download = %q (%s), %v; want the export", data, artifact.ContentType, err)
+	}
+	if _, ok := artifact.Body.(io.Seeker); !ok {
+		t.Error("local export not seekable, so Range requests can't be served")
+	}
+	redemption := artifact.Redemption
+	if redemption == "" || !artifact.ExpiresAt.Equal(link.ExpiresAt) {
+		t.Errorf("redemption = %q until %v, want one until the link expires", redemption, artifact.ExpiresAt)
+	}
+
+	// Only the client that redeemed the link resumes its download, and only
+	// past the first byte
+	tests := []struct {
+		name   string
+		resume DownloadResume
+		want   error
+	}{
+		{"second use", DownloadResume{}, ErrDownloadLinkUsed},
+		{"bytes=0- after the redemption", DownloadResume{Redemption: redemption}, ErrDownloadLinkUsed},
+		{"resumed by another client", DownloadResume{Redemption: uuid.NewString(), Offset: 11}, ErrDownloadLinkUsed},
+		{"resumed without the redemption", DownloadResume{Offset: 11}, ErrDownloadLinkUsed},
+		{"resumed by the client", DownloadResume{Redemption: redemption, Offset: 11}, nil},
+	}
+	for _, tt := range tests {
+		artifact, err := f.svc.RedeemDownload(ctx, f.form.ID, job.ID, token, tt.resume)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+		if err == nil {
+			artifact.Body.Close()
+			if artifact.Redemption != "" {
+				t.Errorf("%s: redeemed the link again", tt.name)
+			}
+		}
+	}
+
+	// Two bytes=0- requests on a fresh link don't both download it
+	link, err = f.svc.DownloadLink(ctx, f.form.ID, job.ID, f.owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, _ = url.Parse(link.URL)
+	fresh := parsed.Query().Get("token")
+	artifact, err = f.svc.RedeemDownload(ctx, f.form.ID, job.ID, fresh, DownloadResume{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	artifact.Body.Close()
+	if _, err := f.svc.RedeemDownload(ctx, f.form.ID, job.ID, fresh, DownloadResume{}); !errors.Is(err, ErrDownloadLinkUsed) {
+		t.Errorf("second bytes=0- request: err = %v, want ErrDownloadLinkUsed", err)
+	}
+
+	link, err = f.svc.DownloadLink(ctx, f.form.ID, job.ID, f.owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, _ = url.Parse(link.URL)
+	now = now.Add(time.Hour)
+	for _, resume := range []DownloadResume{{}, {Redemption: redemption, Offset: 11}} {
+		if _, err := f.svc.RedeemDownload(ctx, f.form.ID, job.ID, parsed.Query().Get("token"), resume); !errors.Is(err, download.ErrInvalidToken) {
+			t.Errorf("expired link (%+v): err = %v, want ErrInvalidToken", resume, err)
+		}
+	}
+	if _, err := f.svc.RedeemDownload(ctx, f.form.ID, job.ID, token, DownloadResume{Redemption: redemption, Offset: 11}); !errors.Is(err, download.ErrInvalidToken) {
+		t.Errorf("resumed after the link expired: err = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestExportPresentedOrder(t *testing.T) {
+	f := newExportFixture(t, 2)
+	plan := &models.Question{FormID: f.form.ID, Type: models.QuestionTypeRadio, Title: "Plan", Order: 3,
+		Display: []byte(`{"randomize_options":true,"pin_last_option":true}`)}
+	if err := f.svc.questionRepo.Create(context.Background(), plan); err != nil {
+		t.Fatal(err)
+	}
+	f.responses.responses[0].Answers[plan.ID.String()] = json.RawMessage(`"pro"`)
+	f.responses.responses[0].PresentedOrder = map[string][]string{plan.ID.String(): {"team", "pro", "free", "other"}}
+
+	job := f.run(t, ExportRequest{Format: models.ExportFormatCSV})
+	if lines := strings.Split(string(f.read(t, job)), "\n"); lines[0] != "response_id,submitted_at,respondent_id,Name,CV,Plan" {
+		t.Errorf("header = %s, want no presented order column by default", lines[0])
+	}
+
+	job = f.run(t, ExportRequest{Format: models.ExportFormatCSV, IncludePresentedOrder: true})
+	lines := strings.Split(string(f.read(t, job)), "\n")
+	if lines[0] != "response_id,submitted_at,respondent_id,Name,CV,Plan,Plan (presented order)" {
+		t.Errorf("header = %s, want the presented order after the randomized question", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], ",pro,team; pro; free; other") {
+		t.Errorf("row = %s, want the answer by key and the presented order", lines[1])
+	}
+	if !strings.HasSuffix(lines[2], ",,") {
+		t.Errorf("row = %s, want empty cells for the unanswered question", lines[2])
+	}
+}
+
+func TestExportLeavesOutTestSubmissions(t *testing.T) {
+	f := newExportFixture(t, 2)
+	f.responses.responses[1].Test = true
+
+	job := f.run(t, ExportRequest{Format: models.ExportFormatCSV})
+	lines := strings.Split(strings.TrimSpace(string(f.read(t, job))), "\n")
+	if len(lines) != 2 || job.Responses != 1 || lines[0] != "response_id,submitted_at,respondent_id,Name,CV" {
+		t.Errorf("exported %d responses:\n%s\nwant the real one, without a test column", job.Responses, strings.Join(lines, "\n"))
+	}
+
+	job = f.run(t, ExportRequest{Format: models.ExportFormatCSV, IncludeTest: true})
+	lines = strings.Split(strings.TrimSpace(string(f.read(t, job))), "\n")
+	if len(lines) != 3 || lines[0] != "response_id,submitted_at,respondent_id,Name,CV,test" {
+		t.Fatalf("export with test submissions:\n%s\nwant both responses and a test column", strings.Join(lines, "\n"))
+	}
+	if !strings.HasSuffix(lines[1], ",false") || !strings.HasSuffix(lines[2], ",true") {
+		t.Errorf("rows = %q, want the second flagged as test", lines[1:])
+	}
+}
+
+func TestExportTimings(t *testing.T) {
+	f := newExportFixture(t, 2)
+	f.responses.timings = map[string]map[string]analytics.QuestionTiming{
+		"resp-000": {f.name.ID.String(): {FocusMs: 8460, Revisits: 2}},
+	}
+
+	job := f.run(t, ExportRequest{Format: models.ExportFormatCSV})
+	lines := strings.Split(strings.TrimSpace(string(f.read(t, job))), "\n")
+	if lines[0] != "response_id,submitted_at,respondent_id,Name,CV" {
+		t.Errorf("header = %s, want no timing columns by default", lines[0])
+	}
+
+	job = f.run(t, ExportRequest{Format: models.ExportFormatCSV, IncludeTimings: true})
+	lines = strings.Split(strings.TrimSpace(string(f.read(t, job))), "\n")
+	if lines[0] != "response_id,submitted_at,respondent_id,Name,Name (seconds),Name (revisits),CV,CV (seconds),CV (revisits)" {
+		t.Fatalf("header = %s, want the timing of each question after it", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], ",Applicant 0,8.5,2,,,") {
+		t.Errorf("row = %s, want the timing of the name", lines[1])
+	}
+	if !strings.HasSuffix(lines[2], ",Applicant 1,,,,,") {
+		t.Errorf("row = %s, want empty timing cells for a response without timings", lines[2])
+	}
+}
+
+func TestExportQuestionKeys(t *testing.T) {
+	f := newExportFixture(t, 2)
+	ctx := context.Background()
+	questions := f.svc.questionRepo.(*memoryQuestions)
+	f.name.Key = "full_name"
+	if err := questions.Update(ctx, f.name); err != nil {
+		t.Fatal(err)
+	}
+	// The first response answered a deleted question under the key the name
+	// was renamed from
+	deleted := uuid.New().String()
+	questions.aliases = append(questions.aliases, models.QuestionKeyAlias{FormID: f.form.ID, Key: "name", QuestionID: f.name.ID})
+	f.responses.responses[0].Answers = map[string]json.RawMessage{deleted: json.RawMessage(`"Ada"`)}
+	f.responses.responses[0].Keys = map[string]string{deleted: "name"}
+
+	job := f.run(t, ExportRequest{Format: models.ExportFormatCSV})
+	lines := strings.Split(strings.TrimSpace(string(f.read(t, job))), "\n")
+	if lines[0] != "response_id,submitted_at,respondent_id,full_name,CV" {
+		t.Errorf("header = %s, want the questions by key, or title without one", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], ",Ada,") || !strings.HasSuffix(lines[2], ",Applicant 1,") {
+		t.Errorf("rows = %q, want the answer under the former key with the name", lines[1:])
+	}
+
+	job = f.run(t, ExportRequest{Format: models.ExportFormatCSV, IncludeLabels: true})
+	lines = strings.Split(strings.TrimSpace(string(f.read(t, job))), "\n")
+	if len(lines) != 4 || lines[1] != "Response ID,Submitted at,Respondent ID,Name,CV" {
+		t.Errorf("export with labels:\n%s\nwant a second header row of titles", strings.Join(lines, "\n"))
+	}
+}
@@ -0,0 +1,788 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/analytics"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+)
+
+// memoryStore holds the repositories of forms, questions, collaborators and
+// organizations in memory, on a clock advanced by the tests. The fixtures of
+// the services embed it.
+type memoryStore struct {
+	clock         *testClock
+	forms         *memoryFormRepository
+	questions     *memoryQuestions
+	collaborators *memoryCollaboratorRepository
+	orgs          *memoryOrganizationRepository
+}
+
+func newMemoryStore(start time.Time) *memoryStore {
+	clock := &testClock{t: start}
+	return &memoryStore{
+		clock:         clock,
+		forms:         newMemoryFormRepository(clock.now),
+		questions:     newMemoryQuestions(),
+		collaborators: newMemoryCollaboratorRepository(),
+		orgs:          newMemoryOrganizationRepository(),
+	}
+}
+
+// createForm stores form, setting its ID
+func (s *memoryStore) createForm(t *testing.T, form *models.Form) *models.Form {
+	t.Helper()
+	if err := s.forms.Create(context.Background(), form); err != nil {
+		t.Fatal(err)
+	}
+	return form
+}
+
+// createNotifiedForm stores a published form of the owner notifying as set
+func (s *memoryStore) createNotifiedForm(t *testing.T, owner uuid.UUID, notifications models.NotificationSettings) *models.Form {
+	t.Helper()
+	settings, err := json.Marshal(models.FormSettings{Notifications: notifications})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s.createForm(t, &models.Form{UserID: owner, Title: "Feedback", Status: models.FormStatusPublished, Settings: settings})
+}
+
+// createQuestions stores the questions, setting their IDs
+func (s *memoryStore) createQuestions(t *testing.T, questions ...*models.Question) {
+	t.Helper()
+	for _, question := range questions {
+		if err := s.questions.Create(context.Background(), question); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// createOrganization creates an organization owned by owner with the members
+func (s *memoryStore) createOrganization(t *testing.T, owner uuid.UUID, members map[uuid.UUID]models.OrganizationRole) *models.Organization {
+	t.Helper()
+	ctx := context.Background()
+	org := &models.Organization{Name: "Acme"}
+	if err := s.orgs.Create(ctx, org, &models.OrganizationMember{UserID: owner, Role: models.OrganizationRoleOwner}); err != nil {
+		t.Fatal(err)
+	}
+	for userID, role := range members {
+		if _, err := s.orgs.AddMember(ctx, &models.OrganizationMember{OrganizationID: org.ID, UserID: userID, Role: role}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return org
+}
+
+// formService creates a form service over the store, publishing and
+// auditing to logs
+func (s *memoryStore) formService() *formService {
+	return NewFormService(s.forms, s.questions, s.collaborators, s.orgs, events.LogPublisher{}, events.LogAuditor{}, nil, nil, nil, nil).(*formService)
+}
+
+// memoryFormRepository keeps forms in memory, stamping updated_at and
+// bumping versions the way the database repository does
+type memoryFormRepository struct {
+	repository.FormRepository
+
+	mu        sync.Mutex
+	forms     map[uuid.UUID]models.Form
+	redirects map[[2]string]models.FormSlugRedirect
+	now       func() time.Time
+}
+
+func newMemoryFormRepository(now func() time.Time) *memoryFormRepository {
+	return &memoryFormRepository{
+		forms:     make(map[uuid.UUID]models.Form),
+		redirects: make(map[[2]string]models.FormSlugRedirect),
+		now:       now,
+	}
+}
+
+func (r *memoryFormRepository) Create(_ context.Context, form *models.Form) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.slugTaken(form) {
+		return repository.ErrSlugTaken
+	}
+	if form.ID == uuid.Nil {
+		form.ID = uuid.New()
+	}
+	if form.Version == 0 {
+		form.Version = 1
+	}
+	form.CreatedAt, form.UpdatedAt = r.now(), r.now()
+	r.forms[form.ID] = *form
+	return nil
+}
+
+func (r *memoryFormRepository) GetByID(_ context.Context, id uuid.UUID) (*models.Form, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	form, ok := r.forms[id]
+	if !ok || form.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &form, nil
+}
+
+func (r *memoryFormRepository) GetInOrganization(ctx context.Context, orgID, id uuid.UUID) (*models.Form, error) {
+	form, err := r.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if form.OrganizationID != orgID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return form, nil
+}
+
+func (r *memoryFormRepository) Update(_ context.Context, form *models.Form) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	form.UpdatedAt = r.now()
+	form.Version = r.forms[form.ID].Version + 1
+	r.forms[form.ID] = *form
+	return nil
+}
+
+func (r *memoryFormRepository) Delete(_ context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	form := r.forms[id]
+	form.UpdatedAt = r.now()
+	form.DeletedAt = gorm.DeletedAt{Time: form.UpdatedAt, Valid: true}
+	r.forms[id] = form
+	return nil
+}
+
+func (r *memoryFormRepository) ListChanges(_ context.Context, userID uuid.UUID, after repository.ChangeCursor, until time.Time, limit int) ([]*models.Form, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var forms []*models.Form
+	for _, form := range r.forms {
+		form := form
+		if form.UserID != userID || form.UpdatedAt.After(until) {
+			continue
+		}
+		if form.UpdatedAt.Before(after.UpdatedAt) ||
+			(form.UpdatedAt.Equal(after.UpdatedAt) && form.ID.String() <= after.ID.String()) {
+			continue
+		}
+		forms = append(forms, &form)
+	}
+	sort.Slice(forms, func(i, j int) bool {
+		if !forms[i].UpdatedAt.Equal(forms[j].UpdatedAt) {
+			return forms[i].UpdatedAt.Before(forms[j].UpdatedAt)
+		}
+		return forms[i].ID.String() < forms[j].ID.String()
+	})
+	if len(forms) > limit {
+		forms = forms[:limit]
+	}
+	return forms, nil
+}
+
+func (r *memoryFormRepository) ListBySlug(_ context.Context, orgID uuid.UUID, slug string) ([]*models.Form, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var forms []*models.Form
+	for _, form := range r.forms {
+		form := form
+		if form.Slug != nil && *form.Slug == slug && !form.DeletedAt.Valid && (orgID == uuid.Nil || form.OrganizationID == orgID) {
+			forms = append(forms, &form)
+		}
+	}
+	return forms, nil
+}
+
+func (r *memoryFormRepository) ListSlugRedirects(_ context.Context, orgID uuid.UUID, slug string, now time.Time) ([]*models.FormSlugRedirect, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var redirects []*models.FormSlugRedirect
+	for _, redirect := range r.redirects {
+		redirect := redirect
+		if redirect.Slug == slug && redirect.ExpiresAt.After(now) && (orgID == uuid.Nil || redirect.OrganizationID == orgID) {
+			redirects = append(redirects, &redirect)
+		}
+	}
+	return redirects, nil
+}
+
+func (r *memoryFormRepository) ChangeSlug(_ context.Context, form *models.Form, previous string, redirectUntil time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.slugTaken(form) {
+		return repository.ErrSlugTaken
+	}
+	form.UpdatedAt = r.now()
+	form.Version = r.forms[form.ID].Version + 1
+	r.forms[form.ID] = *form
+	if form.Slug != nil {
+		delete(r.redirects, [2]string{form.OrganizationID.String(), *form.Slug})
+	}
+	if previous != "" {
+		r.redirects[[2]string{form.OrganizationID.String(), previous}] = models.FormSlugRedirect{
+			OrganizationID: form.OrganizationID,
+			Slug:           previous,
+			FormID:         form.ID,
+			ExpiresAt:      redirectUntil,
+		}
+	}
+	return nil
+}
+
+func (r *memoryFormRepository) SetThrottled(_ context.Context, id uuid.UUID, at *time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	form, ok := r.forms[id]
+	if !ok || (form.ThrottledAt == nil) == (at == nil) {
+		return false, nil
+	}
+	form.ThrottledAt = at
+	r.forms[id] = form
+	return true, nil
+}
+
+func (r *memoryFormRepository) ListCleanupCandidates(_ context.Context, criteria models.CleanupCriteria, after repository.CleanupCursor, limit int) ([]*models.Form, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var forms []*models.Form
+	for _, form := range r.forms {
+		form := form
+		switch {
+		case form.DeletedAt.Valid,
+			criteria.InactiveBefore != nil && !form.UpdatedAt.Before(*criteria.InactiveBefore),
+			criteria.OwnerID != nil && form.UserID != *criteria.OwnerID,
+			criteria.OrganizationID != nil && form.OrganizationID != *criteria.OrganizationID,
+			criteria.Status != "" && form.Status != criteria.Status:
+			continue
+		}
+		if form.CreatedAt.Before(after.CreatedAt) ||
+			(form.CreatedAt.Equal(after.CreatedAt) && form.ID.String() <= after.ID.String()) {
+			continue
+		}
+		forms = append(forms, &form)
+	}
+	sort.Slice(forms, func(i, j int) bool {
+		if !forms[i].CreatedAt.Equal(forms[j].CreatedAt) {
+			return forms[i].CreatedAt.Before(forms[j].CreatedAt)
+		}
+		return forms[i].ID.String() < forms[j].ID.String()
+	})
+	if len(forms) > limit {
+		forms = forms[:limit]
+	}
+	return forms, nil
+}
+
+// slugTaken enforces the unique slug index
+func (r *memoryFormRepository) slugTaken(form *models.Form) bool {
+	if form.Slug == nil {
+		return false
+	}
+	for id, other := range r.forms {
+		if id != form.ID && other.Slug != nil && *other.Slug == *form.Slug &&
+			other.OrganizationID == form.OrganizationID && !other.DeletedAt.Valid {
+			return true
+		}
+	}
+	return false
+}
+
+// testClock is a clock advanced by the tests, in microseconds like PostgreSQL
+type testClock struct{ t time.Time }
+
+func (c *testClock) now() time.Time { return c.t }
+
+func (c *testClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+// memoryQuestions keeps the questions of forms in memory
+type memoryQuestions struct {
+	repository.QuestionRepository
+	mu        sync.Mutex
+	questions map[uuid.UUID]models.Question
+	aliases   []models.QuestionKeyAlias
+}
+
+func newMemoryQuestions() *memoryQuestions {
+	return &memoryQuestions{questions: make(map[uuid.UUID]models.Question)}
+}
+
+func (r *memoryQuestions) Create(_ context.Context, question *models.Question) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if question.ID == uuid.Nil {
+		question.ID = uuid.New()
+	}
+	r.questions[question.ID] = *question
+	return nil
+}
+
+func (r *memoryQuestions) GetByID(_ context.Context, id uuid.UUID) (*models.Question, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	question, ok := r.questions[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return &question, nil
+}
+
+func (r *memoryQuestions) GetByFormID(_ context.Context, formID uuid.UUID) ([]*models.Question, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var questions []*models.Question
+	for _, question := range r.questions {
+		if question.FormID == formID {
+			question := question
+			questions = append(questions, &question)
+		}
+	}
+	return questions, nil
+}
+
+func (r *memoryQuestions) Update(_ context.Context, question *models.Question) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.questions[question.ID] = *question
+	return nil
+}
+
+func (r *memoryQuestions) Delete(_ context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.questions, id)
+	return nil
+}
+
+func (r *memoryQuestions) UpdateOrder(_ context.Context, _ uuid.UUID, orders []repository.QuestionOrder) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, order := range orders {
+		question := r.questions[order.ID]
+		question.Order = order.Order
+		r.questions[order.ID] = question
+	}
+	return nil
+}
+
+func (r *memoryQuestions) GetKeyAliases(_ context.Context, formID uuid.UUID) ([]*models.QuestionKeyAlias, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var aliases []*models.QuestionKeyAlias
+	for _, alias := range r.aliases {
+		if alias.FormID == formID {
+			alias := alias
+			aliases = append(aliases, &alias)
+		}
+	}
+	return aliases, nil
+}
+
+func (r *memoryQuestions) RenameKey(_ context.Context, question *models.Question, alias *models.QuestionKeyAlias) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.aliases[:0]
+	for _, existing := range r.aliases {
+		if existing.FormID != question.FormID || existing.Key != question.Key && existing.Key != alias.Key {
+			kept = append(kept, existing)
+		}
+	}
+	r.aliases = append(kept, *alias)
+	stored := r.questions[question.ID]
+	stored.Key = question.Key
+	r.questions[question.ID] = stored
+	return nil
+}
+
+// memoryCollaboratorRepository keeps collaborators in memory
+type memoryCollaboratorRepository struct {
+	repository.CollaboratorRepository
+
+	mu            sync.Mutex
+	collaborators map[uuid.UUID]models.Collaborator
+}
+
+func newMemoryCollaboratorRepository() *memoryCollaboratorRepository {
+	return &memoryCollaboratorRepository{collaborators: make(map[uuid.UUID]models.Collaborator)}
+}
+
+func (r *memoryCollaboratorRepository) Create(_ context.Context, collaborator *models.Collaborator) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	collaborator.ID = uuid.New()
+	r.collaborators[collaborator.ID] = *collaborator
+	return nil
+}
+
+func (r *memoryCollaboratorRepository) GetByID(_ context.Context, id uuid.UUID) (*models.Collaborator, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	collaborator, ok := r.collaborators[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &collaborator, nil
+}
+
+func (r *memoryCollaboratorRepository) GetByFormID(_ context.Context, formID uuid.UUID) ([]*models.Collaborator, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var collaborators []*models.Collaborator
+	for _, collaborator := range r.collaborators {
+		collaborator := collaborator
+		if collaborator.FormID == formID {
+			collaborators = append(collaborators, &collaborator)
+		}
+	}
+	return collaborators, nil
+}
+
+func (r *memoryCollaboratorRepository) Update(_ context.Context, collaborator *models.Collaborator) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collaborators[collaborator.ID] = *collaborator
+	return nil
+}
+
+func (r *memoryCollaboratorRepository) Delete(_ context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.collaborators, id)
+	return nil
+}
+
+func (r *memoryCollaboratorRepository) FindByFormAndUser(_ context.Context, formID, userID uuid.UUID) (*models.Collaborator, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, collaborator := range r.collaborators {
+		if collaborator.FormID == formID && collaborator.UserID != nil && *collaborator.UserID == userID {
+			return &collaborator, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// recordingAuditor keeps the audit events recorded
+type recordingAuditor struct {
+	mu     sync.Mutex
+	events []events.AuditEvent
+}
+
+func (a *recordingAuditor) Record(_ context.Context, event events.AuditEvent) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.events = append(a.events, event)
+}
+
+func (a *recordingAuditor) actions() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	actions := make([]string, len(a.events))
+	for i, event := range a.events {
+		actions[i] = event.Action
+	}
+	return actions
+}
+
+// memoryOrganizationRepository keeps organizations and members in memory
+type memoryOrganizationRepository struct {
+	repository.OrganizationRepository
+
+	mu      sync.Mutex
+	orgs    map[uuid.UUID]models.Organization
+	members map[[2]uuid.UUID]models.OrganizationMember
+}
+
+func newMemoryOrganizationRepository() *memoryOrganizationRepository {
+	return &memoryOrganizationRepository{
+		orgs:    make(map[uuid.UUID]models.Organization),
+		members: make(map[[2]uuid.UUID]models.OrganizationMember),
+	}
+}
+
+func (r *memoryOrganizationRepository) Create(_ context.Context, org *models.Organization, owner *models.OrganizationMember) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	org.ID = uuid.New()
+	r.orgs[org.ID] = *org
+	owner.OrganizationID = org.ID
+	r.members[[2]uuid.UUID{org.ID, owner.UserID}] = *owner
+	return nil
+}
+
+func (r *memoryOrganizationRepository) GetByID(_ context.Context, id uuid.UUID) (*models.Organization, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	org, ok := r.orgs[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &org, nil
+}
+
+func (r *memoryOrganizationRepository) Personal(ctx context.Context, userID uuid.UUID) (*models.Organization, error) {
+	r.mu.Lock()
+	for _, org := range r.orgs {
+		if org.Personal && org.CreatedBy == userID {
+			r.mu.Unlock()
+			return &org, nil
+		}
+	}
+	r.mu.Unlock()
+
+	org := &models.Organization{Name: models.PersonalOrganizationName, Personal: true, CreatedBy: userID}
+	owner := &models.OrganizationMember{UserID: userID, Role: models.OrganizationRoleOwner}
+	return org, r.Create(ctx, org, owner)
+}
+
+func (r *memoryOrganizationRepository) ListForUser(_ context.Context, userID uuid.UUID) ([]*models.Organization, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var orgs []*models.Organization
+	for key := range r.members {
+		if key[1] == userID {
+			org := r.orgs[key[0]]
+			orgs = append(orgs, &org)
+		}
+	}
+	return orgs, nil
+}
+
+func (r *memoryOrganizationRepository) GetMember(_ context.Context, orgID, userID uuid.UUID) (*models.OrganizationMember, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	member, ok := r.members[[2]uuid.UUID{orgID, userID}]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &member, nil
+}
+
+func (r *memoryOrganizationRepository) AddMember(_ context.Context, member *models.OrganizationMember) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := [2]uuid.UUID{member.OrganizationID, member.UserID}
+	if _, ok := r.members[key]; ok {
+		return false, nil
+	}
+	r.members[key] = *member
+	return true, nil
+}
+
+func (r *memoryOrganizationRepository) ListMembers(_ context.Context, orgID uuid.UUID) ([]*models.OrganizationMember, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var members []*models.OrganizationMember
+	for key, member := range r.members {
+		if key[0] == orgID {
+			member := member
+			members = append(members, &member)
+		}
+	}
+	return members, nil
+}
+
+func (r *memoryOrganizationRepository) ListMemberships(_ context.Context, userID uuid.UUID) ([]*models.OrganizationMember, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var members []*models.OrganizationMember
+	for key, member := range r.members {
+		if key[1] == userID {
+			member := member
+			members = append(members, &member)
+		}
+	}
+	return members, nil
+}
+
+// recordingPublisher records the published events
+type recordingPublisher struct {
+	events []string
+	keys   []string
+	data   []map[string]interface{}
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, eventType, key string, data map[string]interface{}) error {
+	p.events = append(p.events, eventType)
+	p.keys = append(p.keys, key)
+	p.data = append(p.data, data)
+	return nil
+}
+
+// memoryResponses serves responses in submission order, with their
+// timings by response ID
+type memoryResponses struct {
+	responses []analytics.Response
+	timings   map[string]map[string]analytics.QuestionTiming
+}
+
+func (r *memoryResponses) Responses(_ context.Context, _ string, after analytics.ResponseCursor, limit int, includeTest bool) ([]analytics.Response, error) {
+	var page []analytics.Response
+	for _, response := range r.responses {
+		if response.Test && !includeTest {
+			continue
+		}
+		if !response.SubmittedAt.After(after.SubmittedAt) && !(response.SubmittedAt.Equal(after.SubmittedAt) && response.ID > after.ID) {
+			continue
+		}
+		if len(page) == limit {
+			break
+		}
+		page = append(page, response)
+	}
+	return page, nil
+}
+
+func (r *memoryResponses) ResponseTimings(_ context.Context, _ string, responseIDs []string) (map[string]map[string]analytics.QuestionTiming, error) {
+	timings := make(map[string]map[string]analytics.QuestionTiming)
+	for _, id := range responseIDs {
+		if timing, ok := r.timings[id]; ok {
+			timings[id] = timing
+		}
+	}
+	return timings, nil
+}
+
+// memoryFileUploads keeps uploads in memory, handing out copies and stamping
+// created_at on now, when set, the way the database repository does
+type memoryFileUploads struct {
+	repository.FileUploadRepository
+	mu      sync.Mutex
+	uploads []*models.FileUpload
+	now     func() time.Time
+}
+
+func (r *memoryFileUploads) Create(_ context.Context, upload *models.FileUpload) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if upload.Status == "" {
+		upload.Status = models.FileUploadStatusPending
+	}
+	if upload.ScanStatus == "" {
+		upload.ScanStatus = models.FileScanStatusPending
+	}
+	if r.now != nil {
+		upload.CreatedAt = r.now()
+	}
+	stored := *upload
+	r.uploads = append(r.uploads, &stored)
+	return nil
+}
+
+// find returns the upload matching, copied
+func (r *memoryFileUploads) find(match func(*models.FileUpload) bool) (*models.FileUpload, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, upload := range r.uploads {
+		if match(upload) {
+			found := *upload
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memoryFileUploads) GetByID(_ context.Context, id uuid.UUID) (*models.FileUpload, error) {
+	return r.find(func(upload *models.FileUpload) bool { return upload.ID == id })
+}
+
+func (r *memoryFileUploads) GetByToken(_ context.Context, token string) (*models.FileUpload, error) {
+	return r.find(func(upload *models.FileUpload) bool { return upload.Token == token })
+}
+
+func (r *memoryFileUploads) Update(_ context.Context, upload *models.FileUpload) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, stored := range r.uploads {
+		if stored.ID == upload.ID {
+			updated := *upload
+			r.uploads[i] = &updated
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (r *memoryFileUploads) Delete(_ context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, stored := range r.uploads {
+		if stored.ID == id {
+			r.uploads = append(r.uploads[:i], r.uploads[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *memoryFileUploads) ListPendingBefore(_ context.Context, cutoff time.Time, limit int) ([]*models.FileUpload, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var pending []*models.FileUpload
+	for _, upload := range r.uploads {
+		if upload.Status == models.FileUploadStatusPending && upload.CreatedAt.Before(cutoff) && len(pending) < limit {
+			found := *upload
+			pending = append(pending, &found)
+		}
+	}
+	return pending, nil
+}
+
+func (r *memoryFileUploads) ListAwaitingScan(_ context.Context, limit int) ([]*models.FileUpload, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var awaiting []*models.FileUpload
+	for _, upload := range r.uploads {
+		if upload.Status == models.FileUploadStatusAttached && upload.ScanStatus == models.FileScanStatusPending && len(awaiting) < limit {
+			found := *upload
+			awaiting = append(awaiting, &found)
+		}
+	}
+	return awaiting, nil
+}
+
+func (r *memoryFileUploads) ListAttached(_ context.Context, formID uuid.UUID, responseIDs []string) ([]*models.FileUpload, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var attached []*models.FileUpload
+	for _, upload := range r.uploads {
+		for _, id := range responseIDs {
+			if upload.FormID == formID && upload.ResponseID == id {
+				attached = append(attached, upload)
+			}
+		}
+	}
+	return attached, nil
+}
+
+// fakeAggregator returns a summary with the responses set per form, recording
+// the periods asked for
+type fakeAggregator struct {
+	responses map[string]int
+	err       error
+	periods   [][2]time.Time
+}
+
+func (a *fakeAggregator) Summary(_ context.Context, formID string, start, end time.Time) (*analytics.Summary, error) {
+	a.periods = append(a.periods, [2]time.Time{start, end})
+	if a.err != nil {
+		return nil, a.err
+	}
+	n := a.responses[formID]
+	return &analytics.Summary{FormID: formID, TotalResponses: n, CompletedResponses: n, CompletionRate: 100}, nil
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/access"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/scanner"
+	"github.com/Mir00r/X-Form-Backend/shared/storage"
+)
+
+const (
+	// quarantinePrefix is where infected objects are moved to
+	quarantinePrefix = "quarantine/"
+	// downloadURLTTL is how long a pre-signed download URL stays valid
+	downloadURLTTL = 5 * time.Minute
+	// scanQueueSize bounds the number of uploads waiting for the scanner
+	scanQueueSize = 1000
+)
+
+var (
+	// ErrFileQuarantined is returned when downloading a file that failed its scan
+	ErrFileQuarantined = errors.New("file has been quarantined")
+	// ErrFileScanPending is returned in strict mode while a file has not been scanned
+	ErrFileScanPending = errors.New("file scan is still pending")
+)
+
+// FileService defines the interface for scanning and serving uploaded files
+type FileService interface {
+	// Enqueue schedules an upload for scanning
+	Enqueue(upload *models.FileUpload)
+	// Run processes the scan queue until ctx is cancelled
+	Run(ctx context.Context)
+	GetScanStatus(ctx context.Context, id, userID uuid.UUID) (*FileScanStatusResponse, error)
+	GetDownloadURL(ctx context.Context, id, userID uuid.UUID) (string, error)
+}
+
+// FileScanStatusResponse represents the scan state of a file
+type FileScanStatusResponse struct {
+	FileID      uuid.UUID             `json:"file_id"`
+	Status      models.FileScanStatus `json:"status"`
+	Signature   string                `json:"signature,omitempty"`
+	ScannedAt   *time.Time            `json:"scanned_at,omitempty"`
+	Quarantined bool                  `json:"quarantined"`
+}
+
+// fileService implements FileService interface
+type fileService struct {
+	uploadRepo repository.FileUploadRepository
+	guard      formGuard
+	storage    storage.Backend
+	scanner    scanner.Scanner
+	publisher  events.Publisher
+	strictMode bool
+	queue      chan uuid.UUID
+}
+
+// NewFileService creates a new file service instance. Files are served to the
+// users who may view the responses of their form. In strict mode files can't
+// be downloaded until a scan verdict has been recorded.
+func NewFileService(uploadRepo repository.FileUploadRepository, formRepo repository.FormRepository, collaboratorRepo repository.CollaboratorRepository, orgRepo repository.OrganizationRepository, store storage.Backend, fileScanner scanner.Scanner, publisher events.Publisher, strictMode bool) FileService {
+	return &fileService{
+		uploadRepo: uploadRepo,
+		guard:      formGuard{forms: formRepo, collaborators: collaboratorRepo, orgs: organizationScope{orgs: orgRepo}},
+		storage:    store,
+		scanner:    fileScanner,
+		publisher:  publisher,
+		strictMode: strictMode,
+		queue:      make(chan uuid.UUID, scanQueueSize),
+	}
+}
+
+// Enqueue schedules an upload for scanning. When the queue is full the upload
+// is left pending and picked up again on the next restart.
+func (s *fileService) Enqueue(upload *models.FileUpload) {
+	select {
+	case s.queue <- upload.ID:
+	default:
+		log.Printf("Scan queue is full, deferring scan of file %s", upload.ID)
+	}
+}
+
+// Run re-queues uploads left unscanned by a previous run and then scans
+// queued uploads one at a time until ctx is cancelled
+func (s *fileService) Run(ctx context.Context) {
+	if pending, err := s.uploadRepo.ListAwaitingScan(ctx, scanQueueSize); err != nil {
+		log.Printf("Failed to load uploads awaiting scan: %v", err)
+	} else {
+		for _, upload := range pending {
+			s.Enqueue(upload)
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-s.queue:
+			if err := s.scan(ctx, id); err != nil {
+				log.Printf("Failed to scan file %s: %v", id, err)
+			}
+		}
+	}
+}
+
+// scan pulls one object, runs it through the scanner and records the verdict
+func (s *fileService) scan(ctx context.Context, id uuid.UUID) error {
+	upload, err := s.uploadRepo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get upload: %w", err)
+	}
+	if upload.ScanStatus != models.FileScanStatusPending {
+		return nil
+	}
+
+	object, err := s.storage.Open(ctx, upload.ObjectKey)
+	if err != nil {
+		return fmt.Errorf("failed to open object: %w", err)
+	}
+	result, err := s.scanner.Scan(ctx, object)
+	object.Close()
+	if err != nil {
+		// Leave the upload pending so it is retried on the next run
+		return fmt.Errorf("%s scan failed: %w", s.scanner.Name(), err)
+	}
+
+	now := time.Now()
+	upload.ScanStatus = models.FileScanStatus(result.Verdict)
+	upload.ScanSignature = result.Signature
+	upload.ScannedAt = &now
+
+	if result.Verdict == scanner.VerdictInfected {
+		quarantineKey := quarantinePrefix + upload.ObjectKey
+		if err := s.storage.Move(ctx, upload.ObjectKey, quarantineKey); err != nil {
+			return fmt.Errorf("failed to quarantine object: %w", err)
+		}
+		upload.ObjectKey = quarantineKey
+		upload.Quarantined = true
+	}
+
+	if err := s.uploadRepo.Update(ctx, upload); err != nil {
+		return fmt.Errorf("failed to record scan verdict: %w", err)
+	}
+
+	if upload.Quarantined {
+		err := s.publisher.Publish(ctx, events.FileQuarantined, upload.FormID.String(), map[string]interface{}{
+			"file_id":     upload.ID.String(),
+			"form_id":     upload.FormID.String(),
+			"question_id": upload.QuestionID.String(),
+			"response_id": upload.ResponseID,
+			"file_name":   upload.FileName,
+			"signature":   upload.ScanSignature,
+			"scanner":     s.scanner.Name(),
+		})
+		if err != nil {
+			log.Printf("Failed to publish %s event for file %s: %v", events.FileQuarantined, upload.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// GetScanStatus returns the scan state of a file
+func (s *fileService) GetScanStatus(ctx context.Context, id, userID uuid.UUID) (*FileScanStatusResponse, error) {
+	upload, err := s.authorizedUpload(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileScanStatusResponse{
+		FileID:      upload.ID,
+		Status:      upload.ScanStatus,
+		Signature:   upload.ScanSignature,
+		ScannedAt:   upload.ScannedAt,
+		Quarantined: upload.Quarantined,
+	}, nil
+}
+
+// GetDownloadURL returns a short-lived download URL, refusing quarantined
+// files and, in strict mode, files that haven't been scanned yet
+func (s *fileService) GetDownloadURL(ctx context.Context, id, userID uuid.UUID) (string, error) {
+	upload, err := s.authorizedUpload(ctx, id, userID)
+	if err != nil {
+		return "", err
+	}
+
+	if upload.Quarantined || upload.ScanStatus == models.FileScanStatusInfected {
+		return "", ErrFileQuarantined
+	}
+	if s.strictMode && upload.ScanStatus == models.FileScanStatusPending {
+		return "", ErrFileScanPending
+	}
+
+	return s.storage.PresignGet(ctx, upload.ObjectKey, downloadURLTTL)
+}
+
+// authorizedUpload gets an upload if the user may view the responses of its
+// form
+func (s *fileService) authorizedUpload(ctx context.Context, id, userID uuid.UUID) (*models.FileUpload, error) {
+	upload, err := s.uploadRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file: %w", err)
+	}
+	if _, err := s.guard.authorize(ctx, upload.FormID, userID, access.View); err != nil {
+		return nil, err
+	}
+	return upload, nil
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/scanner"
+	"github.com/Mir00r/X-Form-Backend/shared/storage"
+)
+
+// stubScanner returns result, or fails with err when it is set, recording
+// the bodies scanned
+type stubScanner struct {
+	result  scanner.Result
+	err     error
+	scanned []string
+}
+
+func (s *stubScanner) Name() string { return "stub" }
+
+func (s *stubScanner) Scan(_ context.Context, r io.Reader) (scanner.Result, error) {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return scanner.Result{}, err
+	}
+	s.scanned = append(s.scanned, string(body))
+	return s.result, s.err
+}
+
+type fileFixture struct {
+	*memoryStore
+	svc       *fileService
+	uploads   *memoryFileUploads
+	store     storage.Backend
+	scanner   *stubScanner
+	publisher *recordingPublisher
+	upload    *models.FileUpload
+	owner     uuid.UUID
+}
+
+// newFileFixture creates a strict mode file service with an upload attached
+// to a response and awaiting its scan
+func newFileFixture(t *testing.T) *fileFixture {
+	t.Helper()
+	ctx := context.Background()
+	store, err := storage.NewLocalStorage(t.TempDir(), "http://localhost:8001", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &fileFixture{memoryStore: newMemoryStore(time.Now()), uploads: &memoryFileUploads{}, store: store, scanner: &stubScanner{}, publisher: &recordingPublisher{}, owner: uuid.New()}
+	formID := f.createForm(t, &models.Form{UserID: f.owner, Title: "Applications", Status: models.FormStatusPublished}).ID
+	f.upload = &models.FileUpload{
+		ID:         uuid.New(),
+		Token:      "token",
+		FormID:     formID,
+		QuestionID: uuid.New(),
+		ResponseID: "resp-1",
+		ObjectKey:  "uploads/" + formID.String() + "/q/u/cv.pdf",
+		FileName:   "cv.pdf",
+		Status:     models.FileUploadStatusAttached,
+	}
+	if err := f.uploads.Create(ctx, f.upload); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(ctx, f.upload.ObjectKey, "application/pdf", []byte("%PDF-1.7")); err != nil {
+		t.Fatal(err)
+	}
+	f.svc = NewFileService(f.uploads, f.forms, f.collaborators, f.orgs, store, f.scanner, f.publisher, true).(*fileService)
+	return f
+}
+
+func (f *fileFixture) stored(t *testing.T) *models.FileUpload {
+	t.Helper()
+	upload, err := f.uploads.GetByID(context.Background(), f.upload.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return upload
+}
+
+func TestFilesAwaitingTheirScanAreNotServed(t *testing.T) {
+	f := newFileFixture(t)
+	if _, err := f.svc.GetDownloadURL(context.Background(), f.upload.ID, f.owner); !errors.Is(err, ErrFileScanPending) {
+		t.Fatalf("download before the scan: err = %v, want ErrFileScanPending", err)
+	}
+
+	// Without strict mode files are served while their scan is pending
+	lenient := NewFileService(f.uploads, f.forms, f.collaborators, f.orgs, f.store, f.scanner, f.publisher, false)
+	if _, err := lenient.GetDownloadURL(context.Background(), f.upload.ID, f.owner); err != nil {
+		t.Fatalf("download before the scan without strict mode: %v", err)
+	}
+}
+
+func TestCleanVerdictReleasesTheFile(t *testing.T) {
+	f := newFileFixture(t)
+	ctx := context.Background()
+	f.scanner.result = scanner.Result{Verdict: scanner.VerdictClean}
+
+	if err := f.svc.scan(ctx, f.upload.ID); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.scanner.scanned) != 1 || f.scanner.scanned[0] != "%PDF-1.7" {
+		t.Fatalf("scanned %q, want the uploaded object", f.scanner.scanned)
+	}
+	upload := f.stored(t)
+	if upload.ScanStatus != models.FileScanStatusClean || upload.Quarantined || upload.ScannedAt == nil || upload.ObjectKey != f.upload.ObjectKey {
+		t.Fatalf("upload after a clean verdict = %+v", upload)
+	}
+	if _, err := f.svc.GetDownloadURL(ctx, f.upload.ID, f.owner); err != nil {
+		t.Fatalf("download of a clean file: %v", err)
+	}
+	if len(f.publisher.events) != 0 {
+		t.Errorf("published %v for a clean file", f.publisher.events)
+	}
+}
+
+func TestInfectedVerdictRemovesTheFile(t *testing.T) {
+	f := newFileFixture(t)
+	ctx := context.Background()
+	f.scanner.result = scanner.Result{Verdict: scanner.VerdictInfected, Signature: "Eicar-Test-Signature"}
+
+	if err := f.svc.scan(ctx, f.upload.ID); err != nil {
+		t.Fatal(err)
+	}
+	upload := f.stored(t)
+	if upload.ScanStatus != models.FileScanStatusInfected || !upload.Quarantined || upload.ScanSignature != "Eicar-Test-Signature" {
+		t.Fatalf("upload after an infected verdict = %+v", upload)
+	}
+	if _, err := f.store.Stat(ctx, f.upload.ObjectKey); !errors.Is(err, storage.ErrObjectNotFound) {
+		t.Errorf("infected object left at its upload key: %v", err)
+	}
+	if upload.ObjectKey != quarantinePrefix+f.upload.ObjectKey {
+		t.Errorf("infected object moved to %s, want it under the quarantine prefix", upload.ObjectKey)
+	}
+	if _, err := f.svc.GetDownloadURL(ctx, f.upload.ID, f.owner); !errors.Is(err, ErrFileQuarantined) {
+		t.Errorf("download of an infected file: err = %v, want ErrFileQuarantined", err)
+	}
+	if len(f.publisher.events) != 1 || f.publisher.events[0] != events.FileQuarantined {
+		t.Errorf("published %v, want %s", f.publisher.events, events.FileQuarantined)
+	}
+
+	// A quarantined file is never served, even once its verdict is cleared
+	upload.ScanStatus = models.FileScanStatusClean
+	if err := f.uploads.Update(ctx, upload); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.svc.GetDownloadURL(ctx, f.upload.ID, f.owner); !errors.Is(err, ErrFileQuarantined) {
+		t.Errorf("download of a quarantined file: err = %v, want ErrFileQuarantined", err)
+	}
+}
+
+func TestScannerFailureKeepsTheFileHeld(t *testing.T) {
+	f := newFileFixture(t)
+	ctx := context.Background()
+	f.scanner.err = errors.New("clamd: connection refused")
+
+	if err := f.svc.scan(ctx, f.upload.ID); err == nil {
+		t.Fatal("scan succeeded with the scanner down")
+	}
+	upload := f.stored(t)
+	if upload.ScanStatus != models.FileScanStatusPending || upload.ScannedAt != nil {
+		t.Fatalf("upload after a scanner failure = %+v, want it still pending", upload)
+	}
+	if _, err := f.svc.GetDownloadURL(ctx, f.upload.ID, f.owner); !errors.Is(err, ErrFileScanPending) {
+		t.Errorf("download after a scanner failure: err = %v, want ErrFileScanPending", err)
+	}
+
+	// The upload is scanned again once the scanner is back
+	f.scanner.err, f.scanner.result = nil, scanner.Result{Verdict: scanner.VerdictClean}
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		f.svc.Run(runCtx)
+		close(done)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for f.stored(t).ScanStatus == models.FileScanStatusPending && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+	if status := f.stored(t).ScanStatus; status != models.FileScanStatusClean {
+		t.Fatalf("scan status after the retry = %s, want clean", status)
+	}
+}
+
+func TestFilesAreServedToUsersWhoMayViewTheForm(t *testing.T) {
+	f := newFileFixture(t)
+	ctx := context.Background()
+	f.upload.ScanStatus = models.FileScanStatusClean
+	if err := f.uploads.Update(ctx, f.upload); err != nil {
+		t.Fatal(err)
+	}
+	viewerID, invitedID := uuid.New(), uuid.New()
+	viewer := models.Collaborator{FormID: f.upload.FormID, UserID: &viewerID, Role: models.CollaboratorRoleViewer, Status: models.CollaboratorStatusActive}
+	invited := models.Collaborator{FormID: f.upload.FormID, UserID: &invitedID, Role: models.CollaboratorRoleViewer, Status: models.CollaboratorStatusPending}
+	for _, collaborator := range []*models.Collaborator{&viewer, &invited} {
+		if err := f.collaborators.Create(ctx, collaborator); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		userID  uuid.UUID
+		wantErr error
+	}{
+		{"owner", f.owner, nil},
+		{"viewer", viewerID, nil},
+		{"pending invitation", invitedID, ErrNotFormOwner},
+		{"stranger", uuid.New(), ErrNotFormOwner},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := f.svc.GetScanStatus(ctx, f.upload.ID, tt.userID); !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetScanStatus: err = %v, want %v", err, tt.wantErr)
+			}
+			if _, err := f.svc.GetDownloadURL(ctx, f.upload.ID, tt.userID); !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetDownloadURL: err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,773 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/access"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+)
+
+var (
+	// ErrInvalidMerge is returned for changes that can't be merged: unknown
+	// paths, values of the wrong type and base versions the form hasn't
+	// reached
+	ErrInvalidMerge = errors.New("invalid merge")
+	// ErrMergeContended is returned when the form kept changing while changes
+	// were merged into it
+	ErrMergeContended = errors.New("form kept changing during the merge; retry")
+)
+
+// mergeAttempts bounds the merges of a request racing other changes to the
+// form
+const mergeAttempts = 3
+
+// Reasons of merge conflicts
+const (
+	// ConflictEdited is a value changed to another one since the base
+	// version, or a question removed by the editor and changed since
+	ConflictEdited = "edited"
+	// ConflictDeleted is a change to a question deleted since
+	ConflictDeleted = "deleted"
+	// ConflictReordered is a reorder of questions reordered otherwise since
+	ConflictReordered = "reordered"
+	// ConflictExists is an added question whose ID another question has
+	ConflictExists = "exists"
+)
+
+// MergeFormRequest carries the changes an editor made on a version of a form
+type MergeFormRequest struct {
+	// BaseVersion is the version of the form the changes were made on
+	BaseVersion int                         `json:"base_version" binding:"required,min=1" example:"7"`
+	Changes     []models.FormPatchOperation `json:"changes" binding:"required,dive"`
+}
+
+// MergeConflict is a change left out of a merge for the editor to resolve,
+// with the value it was made on, the value on the server and its own
+type MergeConflict struct {
+	Path   string          `json:"path" example:"/title"`
+	Reason string          `json:"reason" enums:"edited,deleted,reordered,exists"`
+	Base   json.RawMessage `json:"base,omitempty" swaggertype:"object"`
+	Server json.RawMessage `json:"server,omitempty" swaggertype:"object"`
+	Client json.RawMessage `json:"client,omitempty" swaggertype:"object"`
+}
+
+// MergeFormResult is a form and its questions after a merge, with the
+// operations applied and the conflicts left out. The version is bumped once
+// when anything was applied.
+type MergeFormResult struct {
+	Form      *models.Form                `json:"form"`
+	Questions []*models.Question          `json:"questions"`
+	Version   int                         `json:"version" example:"8"`
+	Applied   []models.FormPatchOperation `json:"applied"`
+	Conflicts []MergeConflict             `json:"conflicts"`
+}
+
+// MergeForm merges the changes an editor made on a version of a form into
+// its current definition. The changes that don't conflict are applied in one
+// transaction and pushed to the other builders open on the form.
+func (s *formService) MergeForm(ctx context.Context, id uuid.UUID, userID uuid.UUID, req MergeFormRequest) (*MergeFormResult, error) {
+	for attempt := 1; ; attempt++ {
+		result, err := s.mergeForm(ctx, id, userID, req)
+		if !errors.Is(err, repository.ErrVersionChanged) {
+			return result, err
+		}
+		if attempt == mergeAttempts {
+			return nil, ErrMergeContended
+		}
+	}
+}
+
+// mergeForm merges the changes into the current version of the form,
+// failing with repository.ErrVersionChanged when another change commits
+// first
+func (s *formService) mergeForm(ctx context.Context, id uuid.UUID, userID uuid.UUID, req MergeFormRequest) (*MergeFormResult, error) {
+	form, err := s.guard.authorize(ctx, id, userID, access.Edit)
+	if err != nil {
+		return nil, err
+	}
+	if req.BaseVersion > form.Version {
+		return nil, fmt.Errorf("%w: base version %d is ahead of the form at version %d", ErrInvalidMerge, req.BaseVersion, form.Version)
+	}
+	questions, err := s.questionRepo.GetByFormID(ctx, form.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get questions: %w", err)
+	}
+	sort.SliceStable(questions, func(i, j int) bool { return questions[i].Order < questions[j].Order })
+
+	doc, err := newFormDocument(form, questions)
+	if err != nil {
+		return nil, err
+	}
+	applied, conflicts, err := doc.merge(req.Changes)
+	if err != nil {
+		return nil, err
+	}
+	result := &MergeFormResult{
+		Form:      form,
+		Questions: questions,
+		Version:   form.Version,
+		Applied:   applied,
+		Conflicts: conflicts,
+	}
+	if len(applied) == 0 {
+		return result, nil
+	}
+
+	merge, merged, mergedQuestions, err := doc.build(form, questions)
+	if err != nil {
+		return nil, err
+	}
+	// Questions added by the merge get keys from their titles, and may take
+	// those of the questions it deletes
+	if len(merge.Created) > 0 {
+		aliases, err := s.questionRepo.GetKeyAliases(ctx, form.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get question key aliases: %w", err)
+		}
+		if err := assignQuestionKeys(merge.Created, keysTaken(mergedQuestions, aliases, uuid.Nil)); err != nil {
+			return nil, err
+		}
+	}
+	var version int
+	activity := formActivity(form.ID, userID, models.ActivityFormMerged, patchPaths(applied))
+	err = s.activity.record(ctx, activity, func(ctx context.Context) error {
+		version, err = s.formRepo.ApplyMerge(ctx, form.ID, form.Version, merge)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrVersionChanged) {
+			return nil, err
+		}
+		if errors.Is(err, repository.ErrQuestionKeyTaken) {
+			return nil, ErrQuestionKeyTaken
+		}
+		return nil, fmt.Errorf("failed to merge form: %w", err)
+	}
+	merged.Version = version
+	s.publishFormChanged(ctx, events.FormUpdated, merged, slugOf(merged))
+	s.activity.publishPatch(ctx, &models.FormPatch{
+		FormID:          form.ID,
+		ActorID:         userID,
+		PreviousVersion: form.Version,
+		Version:         version,
+		Operations:      applied,
+		CreatedAt:       s.now().UTC(),
+	})
+
+	result.Form, result.Questions, result.Version = merged, mergedQuestions, version
+	return result, nil
+}
+
+// patchPaths names the paths operations changed, for their activity
+func patchPaths(operations []models.FormPatchOperation) []string {
+	var paths []string
+	for _, op := range operations {
+		path := strings.TrimPrefix(op.Path, "/")
+		if !slices.Contains(paths, path) {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// mergeFormFields are the paths of the fields of forms patches replace,
+// besides their settings
+var mergeFormFields = []string{"/title", "/description", "/retention_days", "/rules"}
+
+// mergeQuestionFields are the fields of questions patches replace
+var mergeQuestionFields = []string{"type", "title", "description", "options", "validation", "display", "results_visibility"}
+
+// settingsKeys are the keys of the settings of forms, each replaced on its
+// own by patches
+var settingsKeys = func() map[string]bool {
+	keys := make(map[string]bool)
+	t := reflect.TypeOf(models.FormSettings{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		keys[name] = true
+	}
+	return keys
+}()
+
+// patchTarget is what the path of a patch operation changes
+type patchTarget struct {
+	// question is the question changed, uuid.Nil for the form
+	question uuid.UUID
+	// field is the field of the question changed, empty when the question is
+	// added or removed, or the path of the field of the form changed
+	field string
+	// order is set for the question order
+	order bool
+}
+
+// parsePatchPath returns the target of op, checking op applies to it
+func parsePatchPath(op models.FormPatchOperation) (patchTarget, error) {
+	invalid := func(reason string) (patchTarget, error) {
+		return patchTarget{}, fmt.Errorf("%w: %s %s: %s", ErrInvalidMerge, op.Op, op.Path, reason)
+	}
+
+	var target patchTarget
+	parts := strings.Split(strings.TrimPrefix(op.Path, "/"), "/")
+	switch {
+	case !strings.HasPrefix(op.Path, "/"):
+		return invalid("paths start with /")
+	case op.Path == "/question_order":
+		target.order = true
+	case parts[0] == "questions" && (len(parts) == 2 || len(parts) == 3):
+		id, err := uuid.Parse(parts[1])
+		if err != nil || id == uuid.Nil {
+			return invalid("invalid question ID")
+		}
+		target.question = id
+		if len(parts) == 3 {
+			if !slices.Contains(mergeQuestionFields, parts[2]) {
+				return invalid("unknown question field")
+			}
+			target.field = parts[2]
+			break
+		}
+		switch {
+		case op.Op == models.PatchReplace:
+			return invalid("questions are added or removed; replace their fields")
+		case op.Op == models.PatchAdd && len(op.Value) == 0:
+			return invalid("the value is the question added")
+		case op.Op == models.PatchRemove && len(op.Base) == 0:
+			return invalid("the base is the question removed, as last seen")
+		}
+		return target, nil
+	case len(parts) == 1 && slices.Contains(mergeFormFields, op.Path):
+		target.field = op.Path
+	case len(parts) == 2 && parts[0] == "settings" && settingsKeys[parts[1]]:
+		target.field = op.Path
+	default:
+		return invalid("unknown path")
+	}
+	if op.Op != models.PatchReplace {
+		return invalid("the path is only replaced")
+	}
+	return target, nil
+}
+
+// formDocument is the definition of a form as the values of the paths of
+// patch operations, which merges compare and change
+type formDocument struct {
+	// fields holds the values of the paths of the form, settings included
+	fields    map[string]json.RawMessage
+	questions map[uuid.UUID]map[string]json.RawMessage
+	order     []uuid.UUID
+
+	// What the merge changed
+	formChanged     bool
+	settingsChanged bool
+	changed         map[uuid.UUID]bool
+	reordered       bool
+}
+
+// newFormDocument returns the document of a form and its questions, in order
+func newFormDocument(form *models.Form, questions []*models.Question) (*formDocument, error) {
+	settings, err := form.GetSettings()
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := json.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+	var settingsFields map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &settingsFields); err != nil {
+		return nil, err
+	}
+
+	doc := &formDocument{
+		fields: map[string]json.RawMessage{
+			"/title":          encodeValue(form.Title),
+			"/description":    encodeValue(form.Description),
+			"/retention_days": encodeValue(form.RetentionDays),
+			"/rules":          jsonValue(json.RawMessage(form.Rules)),
+		},
+		questions: make(map[uuid.UUID]map[string]json.RawMessage, len(questions)),
+		changed:   make(map[uuid.UUID]bool),
+	}
+	for key, value := range settingsFields {
+		doc.fields["/settings/"+key] = value
+	}
+	for _, q := range questions {
+		doc.questions[q.ID] = map[string]json.RawMessage{
+			"type":               encodeValue(q.Type),
+			"title":              encodeValue(q.Title),
+			"description":        encodeValue(q.Description),
+			"options":            jsonValue(json.RawMessage(q.Options)),
+			"validation":         jsonValue(json.RawMessage(q.Validation)),
+			"display":            jsonValue(json.RawMessage(q.Display)),
+			"results_visibility": encodeValue(q.ResultsVisibility),
+		}
+		doc.order = append(doc.order, q.ID)
+	}
+	return doc, nil
+}
+
+// merge applies to the document the changes an editor made on a past version
+// of it, as a three-way merge: a change applies when the value still is the
+// one the editor changed, is skipped when it already is the one the editor
+// set, and conflicts otherwise. The question order is merged last, once the
+// questions are added and removed. It returns the operations applied, as
+// applied, and the conflicts.
+func (d *formDocument) merge(changes []models.FormPatchOperation) ([]models.FormPatchOperation, []MergeConflict, error) {
+	applied := []models.FormPatchOperation{}
+	conflicts := []MergeConflict{}
+	var order *models.FormPatchOperation
+	for i, op := range changes {
+		target, err := parsePatchPath(op)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var result *models.FormPatchOperation
+		var conflict *MergeConflict
+		switch {
+		case target.order:
+			if order != nil {
+				return nil, nil, fmt.Errorf("%w: %s is changed twice", ErrInvalidMerge, op.Path)
+			}
+			order = &changes[i]
+			continue
+		case target.question == uuid.Nil:
+			result, conflict = d.replaceField(target.field, op)
+		case target.field == "" && op.Op == models.PatchAdd:
+			result, conflict, err = d.addQuestion(target.question, op)
+		case target.field == "":
+			result, conflict, err = d.removeQuestion(target.question, op)
+		default:
+			result, conflict = d.replaceQuestionField(target.question, target.field, op)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if result != nil {
+			applied = append(applied, *result)
+		}
+		if conflict != nil {
+			conflicts = append(conflicts, *conflict)
+		}
+	}
+
+	if order != nil {
+		result, conflict, err := d.reorder(*order)
+		if err != nil {
+			return nil, nil, err
+		}
+		if result != nil {
+			applied = append(applied, *result)
+		}
+		if conflict != nil {
+			conflicts = append(conflicts, *conflict)
+		}
+	}
+	return applied, conflicts, nil
+}
+
+// replaceField merges the change of a field of the form
+func (d *formDocument) replaceField(path string, op models.FormPatchOperation) (*models.FormPatchOperation, *MergeConflict) {
+	apply, conflict := mergeValue(d.fields[path], op)
+	if !apply {
+		return nil, conflict
+	}
+	d.fields[path] = jsonValue(op.Value)
+	d.formChanged = true
+	if strings.HasPrefix(path, "/settings/") {
+		d.settingsChanged = true
+	}
+	return appliedOperation(op), nil
+}
+
+// replaceQuestionField merges the change of a field of a question, which
+// conflicts when the question was deleted since
+func (d *formDocument) replaceQuestionField(id uuid.UUID, field string, op models.FormPatchOperation) (*models.FormPatchOperation, *MergeConflict) {
+	fields, ok := d.questions[id]
+	if !ok {
+		return nil, &MergeConflict{Path: op.Path, Reason: ConflictDeleted, Base: op.Base, Client: op.Value}
+	}
+	apply, conflict := mergeValue(fields[field], op)
+	if !apply {
+		return nil, conflict
+	}
+	fields[field] = jsonValue(op.Value)
+	d.changed[id] = true
+	return appliedOperation(op), nil
+}
+
+// addQuestion adds a question at the end of the form. Adding it again, as a
+// retried merge does, is skipped.
+func (d *formDocument) addQuestion(id uuid.UUID, op models.FormPatchOperation) (*models.FormPatchOperation, *MergeConflict, error) {
+	fields, err := questionFields(op.Value)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: add %s: %v", ErrInvalidMerge, op.Path, err)
+	}
+	if current, ok := d.questions[id]; ok {
+		if sameQuestion(current, fields) {
+			return nil, nil, nil
+		}
+		return nil, &MergeConflict{Path: op.Path, Reason: ConflictExists, Server: encodeValue(current), Client: op.Value}, nil
+	}
+	d.questions[id] = fields
+	d.order = append(d.order, id)
+	d.changed[id] = true
+	return appliedOperation(op), nil, nil
+}
+
+// removeQuestion removes a question unless it changed since the editor saw
+// it. Removing a question deleted since is skipped.
+func (d *formDocument) removeQuestion(id uuid.UUID, op models.FormPatchOperation) (*models.FormPatchOperation, *MergeConflict, error) {
+	base, err := questionFields(op.Base)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: remove %s: %v", ErrInvalidMerge, op.Path, err)
+	}
+	current, ok := d.questions[id]
+	if !ok {
+		return nil, nil, nil
+	}
+	if !sameQuestion(current, base) {
+		return nil, &MergeConflict{Path: op.Path, Reason: ConflictEdited, Base: op.Base, Server: encodeValue(current)}, nil
+	}
+	delete(d.questions, id)
+	delete(d.changed, id)
+	d.order = slices.DeleteFunc(d.order, func(q uuid.UUID) bool { return q == id })
+	return appliedOperation(op), nil, nil
+}
+
+// reorder merges a new order of the questions. It applies when the
+// questions the editor saw are still in the order it saw them, whatever was
+// added or removed since: the questions keep the order of the editor, and
+// those it didn't know of follow.
+func (d *formDocument) reorder(op models.FormPatchOperation) (*models.FormPatchOperation, *MergeConflict, error) {
+	var client, base []uuid.UUID
+	if err := json.Unmarshal(jsonValue(op.Value), &client); err != nil {
+		return nil, nil, fmt.Errorf("%w: %s: the value is the question IDs in order", ErrInvalidMerge, op.Path)
+	}
+	if err := json.Unmarshal(jsonValue(op.Base), &base); err != nil {
+		return nil, nil, fmt.Errorf("%w: %s: the base is the question IDs in the order last seen", ErrInvalidMerge, op.Path)
+	}
+	seen := make(map[uuid.UUID]bool, len(client))
+	for _, id := range client {
+		if seen[id] {
+			return nil, nil, fmt.Errorf("%w: %s: question %s is listed twice", ErrInvalidMerge, op.Path, id)
+		}
+		seen[id] = true
+	}
+
+	if !sameOrder(d.order, base) {
+		if sameOrder(d.order, client) {
+			return nil, nil, nil
+		}
+		return nil, &MergeConflict{Path: op.Path, Reason: ConflictReordered, Base: op.Base, Server: encodeValue(d.order), Client: op.Value}, nil
+	}
+
+	order := make([]uuid.UUID, 0, len(d.order))
+	for _, id := range client {
+		if _, ok := d.questions[id]; ok {
+			order = append(order, id)
+		}
+	}
+	for _, id := range d.order {
+		if !seen[id] {
+			order = append(order, id)
+		}
+	}
+	if slices.Equal(order, d.order) {
+		return nil, nil, nil
+	}
+	d.order = order
+	d.reordered = true
+	return &models.FormPatchOperation{Op: op.Op, Path: op.Path, Value: encodeValue(order)}, nil, nil
+}
+
+// build returns the changes the merge made to the form and its questions,
+// validating them as the form and question endpoints do, along with the
+// merged form and its questions in order
+func (d *formDocument) build(form *models.Form, questions []*models.Question) (repository.FormMerge, *models.Form, []*models.Question, error) {
+	var merge repository.FormMerge
+	merged := *form
+	if d.formChanged {
+		if err := d.buildForm(&merged); err != nil {
+			return merge, nil, nil, err
+		}
+		merge.Form = &merged
+	}
+
+	existing := make(map[uuid.UUID]*models.Question, len(questions))
+	maxOrder := 0
+	for _, q := range questions {
+		existing[q.ID] = q
+		maxOrder = max(maxOrder, q.Order)
+	}
+	mergedQuestions := make([]*models.Question, 0, len(d.order))
+	for i, id := range d.order {
+		question := &models.Question{ID: id, FormID: form.ID}
+		before, ok := existing[id]
+		if ok {
+			copied := *before
+			question = &copied
+		}
+		if d.changed[id] {
+			if err := buildQuestion(question, d.questions[id]); err != nil {
+				return merge, nil, nil, err
+			}
+		}
+
+		switch {
+		case d.reordered:
+			question.Order = i + 1
+		case !ok:
+			maxOrder++
+			question.Order = maxOrder
+		}
+		switch {
+		case !ok:
+			merge.Created = append(merge.Created, question)
+		case d.changed[id]:
+			merge.Updated = append(merge.Updated, question)
+		case question.Order != before.Order:
+			merge.Order = append(merge.Order, repository.QuestionOrder{ID: id, Order: question.Order})
+		}
+		mergedQuestions = append(mergedQuestions, question)
+	}
+	for _, q := range questions {
+		if _, ok := d.questions[q.ID]; !ok {
+			merge.Deleted = append(merge.Deleted, q.ID)
+		}
+	}
+
+	// The rules must hold up with the merged questions, whoever changed them
+	rules, err := merged.GetRules()
+	if err != nil {
+		return merge, nil, nil, err
+	}
+	if len(rules) > 0 {
+		if err := models.ValidateRules(rules, mergedQuestions); err != nil {
+			return merge, nil, nil, fmt.Errorf("%w: %v", ErrInvalidRules, err)
+		}
+	}
+	return merge, &merged, mergedQuestions, nil
+}
+
+// buildForm sets the fields of the form to those of the document
+func (d *formDocument) buildForm(form *models.Form) error {
+	var retentionDays *int
+	var rules []models.FormRule
+	for path, dest := range map[string]interface{}{
+		"/title":          &form.Title,
+		"/description":    &form.Description,
+		"/retention_days": &retentionDays,
+		"/rules":          &rules,
+	} {
+		if err := json.Unmarshal(d.fields[path], dest); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidMerge, path, err)
+		}
+	}
+
+	if retentionDays != nil && *retentionDays == 0 {
+		retentionDays = nil
+	}
+	if retentionDays != nil {
+		if err := models.ValidateRetentionDays(*retentionDays); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidRetention, err)
+		}
+	}
+	form.RetentionDays = retentionDays
+	if err := form.SetRules(rules); err != nil {
+		return err
+	}
+
+	if d.settingsChanged {
+		fields := make(map[string]json.RawMessage)
+		for path, value := range d.fields {
+			if key, ok := strings.CutPrefix(path, "/settings/"); ok {
+				fields[key] = value
+			}
+		}
+		encoded, err := json.Marshal(fields)
+		if err != nil {
+			return err
+		}
+		var settings models.FormSettings
+		if err := json.Unmarshal(encoded, &settings); err != nil {
+			return fmt.Errorf("%w: settings: %v", ErrInvalidMerge, err)
+		}
+		if form.Settings, err = encodeSettings(settings); err != nil {
+			return err
+		}
+	}
+
+	if err := form.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMerge, err)
+	}
+	return nil
+}
+
+// buildQuestion sets the fields of a question to those of the document
+func buildQuestion(question *models.Question, fields map[string]json.RawMessage) error {
+	for field, dest := range map[string]interface{}{
+		"type":               &question.Type,
+		"title":              &question.Title,
+		"description":        &question.Description,
+		"results_visibility": &question.ResultsVisibility,
+	} {
+		if err := json.Unmarshal(fields[field], dest); err != nil {
+			return fmt.Errorf("%w: question %s: %s: %v", ErrInvalidMerge, question.ID, field, err)
+		}
+	}
+	question.Options = jsonField(fields["options"])
+	question.Validation = jsonField(fields["validation"])
+	question.Display = jsonField(fields["display"])
+
+	if err := validateResultsVisibility(question); err != nil {
+		return err
+	}
+	if err := validateDisplay(question); err != nil {
+		return err
+	}
+	if err := question.Validate(); err != nil {
+		return fmt.Errorf("%w: question %s: %v", ErrInvalidMerge, question.ID, err)
+	}
+	return nil
+}
+
+// questionFields decodes the fields of a question merges change from its
+// JSON, ignoring the others
+func questionFields(raw json.RawMessage) (map[string]json.RawMessage, error) {
+	var decoded map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &decoded); err != nil || decoded == nil {
+		return nil, fmt.Errorf("a question is a JSON object")
+	}
+	fields := make(map[string]json.RawMessage, len(mergeQuestionFields))
+	for _, field := range mergeQuestionFields {
+		fields[field] = jsonValue(decoded[field])
+	}
+	return fields, nil
+}
+
+// sameQuestion reports whether two questions have the same fields
+func sameQuestion(a, b map[string]json.RawMessage) bool {
+	for _, field := range mergeQuestionFields {
+		if !sameValue(a[field], b[field]) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameOrder reports whether the questions in both orders are in the same
+// order, ignoring the questions of one only
+func sameOrder(a, b []uuid.UUID) bool {
+	inA := make(map[uuid.UUID]bool, len(a))
+	for _, id := range a {
+		inA[id] = true
+	}
+	inB := make(map[uuid.UUID]bool, len(b))
+	for _, id := range b {
+		inB[id] = true
+	}
+	common := func(order []uuid.UUID, in map[uuid.UUID]bool) []uuid.UUID {
+		var kept []uuid.UUID
+		for _, id := range order {
+			if in[id] {
+				kept = append(kept, id)
+			}
+		}
+		return kept
+	}
+	return slices.Equal(common(a, inB), common(b, inA))
+}
+
+// mergeValue merges a change of a value from op.Base to op.Value into the
+// current value: it applies when current still is the base, is skipped when
+// current already is the value, and conflicts otherwise
+func mergeValue(current json.RawMessage, op models.FormPatchOperation) (bool, *MergeConflict) {
+	switch {
+	case sameValue(current, op.Value):
+		return false, nil
+	case sameValue(current, op.Base):
+		return true, nil
+	}
+	return false, &MergeConflict{Path: op.Path, Reason: ConflictEdited, Base: op.Base, Server: jsonValue(current), Client: op.Value}
+}
+
+// sameValue reports whether two JSON values are the same. Missing values,
+// null and empty values are all the same, as settings and questions omit
+// their empty fields.
+func sameValue(a, b json.RawMessage) bool {
+	return reflect.DeepEqual(decodeValue(a), decodeValue(b))
+}
+
+// decodeValue decodes a JSON value, to nil when it is empty
+func decodeValue(raw json.RawMessage) interface{} {
+	var v interface{}
+	if json.Unmarshal(jsonValue(raw), &v) != nil {
+		return nil
+	}
+	switch value := v.(type) {
+	case string:
+		if value == "" {
+			return nil
+		}
+	case float64:
+		if value == 0 {
+			return nil
+		}
+	case bool:
+		if !value {
+			return nil
+		}
+	case []interface{}:
+		if len(value) == 0 {
+			return nil
+		}
+	case map[string]interface{}:
+		if len(value) == 0 {
+			return nil
+		}
+	}
+	return v
+}
+
+// appliedOperation returns op as applied, without its base
+func appliedOperation(op models.FormPatchOperation) *models.FormPatchOperation {
+	op.Base = nil
+	return &op
+}
+
+// jsonValue returns raw, or null when it is missing
+func jsonValue(raw json.RawMessage) json.RawMessage {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return json.RawMessage("null")
+	}
+	return raw
+}
+
+// jsonField returns the value of a JSON column, nil for null
+func jsonField(raw json.RawMessage) datatypes.JSON {
+	if string(jsonValue(raw)) == "null" {
+		return nil
+	}
+	return datatypes.JSON(raw)
+}
+
+// encodeValue encodes v, which always encodes
+func encodeValue(v interface{}) json.RawMessage {
+	data, _ := json.Marshal(v)
+	return data
+}
@@ -0,0 +1,680 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+)
+
+// mergeFixture is a form with the questions name, email and rating, in that
+// order, as an editor saw it. The tests change it as another editor did on
+// the server before merging the changes of the first.
+type mergeFixture struct {
+	form      *models.Form
+	questions []*models.Question
+	ids       map[string]uuid.UUID
+}
+
+func newMergeFixture() *mergeFixture {
+	f := &mergeFixture{
+		form: &models.Form{
+			ID:          uuid.New(),
+			Title:       "Feedback",
+			Description: "Tell us",
+			Status:      models.FormStatusDraft,
+			Settings:    []byte(`{"accepting_responses":true,"show_progress_bar":false}`),
+			Version:     1,
+		},
+		ids: make(map[string]uuid.UUID),
+	}
+	for i, q := range []struct {
+		key string
+		typ models.QuestionType
+	}{{"name", models.QuestionTypeText}, {"email", models.QuestionTypeEmail}, {"rating", models.QuestionTypeRadio}} {
+		question := &models.Question{ID: uuid.New(), FormID: f.form.ID, Type: q.typ, Title: q.key, Order: i + 1, ResultsVisibility: models.ResultsVisibilityPrivate}
+		f.questions = append(f.questions, question)
+		f.ids[q.key] = question.ID
+	}
+	f.question("rating").Options = []byte(`["good","bad"]`)
+	return f
+}
+
+func (f *mergeFixture) question(key string) *models.Question {
+	for _, q := range f.questions {
+		if q.ID == f.ids[key] {
+			return q
+		}
+	}
+	return nil
+}
+
+// delete deletes a question on the server
+func (f *mergeFixture) delete(key string) {
+	for i, q := range f.questions {
+		if q.ID == f.ids[key] {
+			f.questions = append(f.questions[:i], f.questions[i+1:]...)
+			return
+		}
+	}
+}
+
+// reorder reorders the questions on the server
+func (f *mergeFixture) reorder(keys ...string) {
+	for i, key := range keys {
+		f.question(key).Order = i + 1
+	}
+	sort.SliceStable(f.questions, func(i, j int) bool { return f.questions[i].Order < f.questions[j].Order })
+}
+
+// add adds a question on the server
+func (f *mergeFixture) add(key string) {
+	question := &models.Question{ID: uuid.New(), FormID: f.form.ID, Type: models.QuestionTypeText, Title: key, Order: len(f.questions) + 1}
+	f.questions = append(f.questions, question)
+	f.ids[key] = question.ID
+}
+
+func (f *mergeFixture) order(keys ...string) []uuid.UUID {
+	ids := make([]uuid.UUID, len(keys))
+	for i, key := range keys {
+		ids[i] = f.ids[key]
+	}
+	return ids
+}
+
+func (f *mergeFixture) path(key string, field ...string) string {
+	path := "/questions/" + f.ids[key].String()
+	for _, part := range field {
+		path += "/" + part
+	}
+	return path
+}
+
+func encode(v interface{}) json.RawMessage {
+	data, _ := json.Marshal(v)
+	return data
+}
+
+func replaceOp(path string, base, value interface{}) models.FormPatchOperation {
+	return models.FormPatchOperation{Op: models.PatchReplace, Path: path, Base: encode(base), Value: encode(value)}
+}
+
+// baseQuestion is a question as the editor saw it, in the fixture
+func baseQuestion(key string) json.RawMessage {
+	return encode(newMergeFixture().question(key))
+}
+
+func TestMergeMatrix(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		// server changes the form before the merge
+		server func(f *mergeFixture)
+		// changes are the changes of the editor merged
+		changes func(f *mergeFixture) []models.FormPatchOperation
+		// applied and conflicts are the paths applied and the paths in
+		// conflict with their reason, the question keys between braces
+		applied   []string
+		conflicts []string
+		// check checks the merged form and questions
+		check func(t *testing.T, f *mergeFixture, form *models.Form, questions []*models.Question)
+	}{
+		// Disjoint field edits
+		{
+			name:   "form fields changed apart",
+			server: func(f *mergeFixture) { f.form.Title = "Survey" },
+			changes: func(f *mergeFixture) []models.FormPatchOperation {
+				return []models.FormPatchOperation{replaceOp("/description", "Tell us", "Tell us more")}
+			},
+			applied: []string{"/description"},
+			check: func(t *testing.T, f *mergeFixture, form *models.Form, _ []*models.Question) {
+				if form.Title != "Survey" || form.Description != "Tell us more" {
+					t.Errorf("form = %q, %q; want both changes", form.Title, form.Description)
+				}
+			},
+		},
+		{
+			name: "settings changed apart",
+			server: func(f *mergeFixture) {
+				f.form.Settings = []byte(`{"accepting_responses":false,"show_progress_bar":false}`)
+			},
+			changes: func(f *mergeFixture) []models.FormPatchOperation {
+				return []models.FormPatchOperation{replaceOp("/settings/show_progress_bar", false, true)}
+			},
+			applied: []string{"/settings/show_progress_bar"},
+			check: func(t *testing.T, f *mergeFixture, form *models.Form, _ []*models.Question) {
+				settings, _ := form.GetSettings()
+				if settings.AcceptingResponses || !settings.ShowProgressBar {
+					t.Errorf("settings = %+v, want both changes", settings)
+				}
+			},
+		},
+		{
+			name:   "questions changed apart",
+			server: func(f *mergeFixture) { f.question("name").Title = "Full name" },
+			changes: func(f *mergeFixture) []models.FormPatchOperation {
+				return []models.FormPatchOperation{replaceOp(f.path("email", "title"), "email", "Work email")}
+			},
+			applied: []string{"{email}/title"},
+			check: func(t *testing.T, f *mergeFixture, _ *models.Form, questions []*models.Question) {
+				if questions[0].Title != "Full name" || questions[1].Title != "Work email" {
+					t.Errorf("titles = %q, %q; want both changes", questions[0].Title, questions[1].Title)
+				}
+			},
+		},
+		{
+			name:   "fields of a question changed apart",
+			server: func(f *mergeFixture) { f.question("name").Title = "Full name" },
+			changes: func(f *mergeFixture) []models.FormPatchOperation {
+				return []models.FormPatchOperation{replaceOp(f.path("name", "description"), "", "As on your passport")}
+			},
+			applied: []string{"{name}/description"},
+			check: func(t *testing.T, f *mergeFixture, _ *models.Form, questions []*models.Question) {
+				if questions[0].Title != "Full name" || questions[0].Description != "As on your passport" {
+					t.Errorf("question = %q, %q; want both changes", questions[0].Title, questions[0].Description)
+				}
+			},
+		},
+		{
+			name: "form unchanged since",
+			changes: func(f *mergeFixture) []models.FormPatchOperation {
+				return []models.FormPatchOperation{
+					replaceOp("/title", "Feedback", "Product feedback"),
+					replaceOp("/retention_days", nil, 90),
+					replaceOp(f.path("rating", "options"), []string{"good", "bad"}, []string{"good", "fair", "bad"}),
+				}
+			},
+			applied: []string{"/title", "/retention_days", "{rating}/options"},
+			check: func(t *testing.T, f *mergeFixture, form *models.Form, questions []*models.Question) {
+				if form.Title != "Product feedback" || form.RetentionDays == nil || *form.RetentionDays != 90 {
+					t.Errorf("form = %q retained %v, want the changes", form.Title, form.RetentionDays)
+				}
+				if string(questions[2].Options) != `["good","fair","bad"]` {
+					t.Errorf("options = %s, want the change", questions[2].Options)
+				}
+			},
+		},
+
+		// Same-field edits
+		{
+			name:   "form field changed twice",
+			server: func(f *mergeFixture) { f.form.Title = "Survey" },
+			changes: func(f *mergeFixture) []models.FormPatchOperation {
+				return []models.FormPatchOperation{replaceOp("/title", "Feedback", "Product feedback"), replaceOp("/description", "Tell us", "")}
+			},
+			applied:   []string{"/description"},
+			conflicts: []string{"/title edited"},
+			check: func(t *testing.T, f *mergeFixture, form *models.Form, _ []*models.Question) {
+				if form.Title != "Survey" || form.Description != "" {
+					t.Errorf("form = %q, %q; want the server title and the editor description", form.Title, form.Description)
+				}
+			},
+		},
+		{
+			name:   "form field changed alike",
+			server: func(f *mergeFixture) { f.form.Title = "Survey" },
+			changes: func(f *mergeFixture) []models.FormPatchOperation {
+				return []models.FormPatchOperation{replaceOp("/title", "Feedback", "Survey")}
+			},
+		},
+		{
+			name: "setting changed twice",
+			server: func(f *mergeFixture) {
+				f.form.Settings = []byte(`{"accepting_responses":true,"confirmation_message":"Thanks"}`)
+			},
+			changes: func(f *mergeFixture) []models.FormPatchOperation {
+				return []models.FormPatchOperation{replaceOp("/settings/confirmation_message", "", "Thank you!")}
+			},
+			conflicts: []string{"/settings/confirmation_message edited"},
+		},
+		{
+			name:   "question field changed twice",
+			server: func(f *mergeFixture) { f.question("name").Title = "Full name" },
+			changes: func(f *mergeFixture) []models.FormPatchOperation {
+				return []models.FormPatchOperation{replaceOp(f.path("name", "title"), "name", "Your name")}
+			},
+			conflicts: []string{"{name}/title edited"},
+		},
+		{
+			name:   "question field changed alike",
+			server: func(f *mergeFixture) { f.question("name").Title = "Full name" },
+			changes: func(f *mergeFixture) []models.FormPatchOperation {
+				return []models.FormPatchOperation{replaceOp(f.path("name", "title"), "name", "Full name")}
+			},
+		},
+
+		// Question reorder vs. edit
+		{
+			name:   "question edited while reordered on the server",
+			server: func(f *mergeFixture) { f.reorder("rating", "name", "email") },
+			changes: func(f *mergeFixture) []models.FormPatchOperation {
+				return []models.FormPatchOperation{replaceOp(f.path("name", "title"), "name", "Full name")}
+			},
+			applied: []string{"{name}/title"},
+			check: func(t *testing.T, f *mergeFixture, _ *models.Form, questions []*models.Question) {
+				if !reflect.DeepEqual(questionIDs(questions), f.order("rating", "name", "email")) || questions[1].Title != "Full name" {
+					t.Errorf("questions = %v, want the server order with the edit", questionIDs(questions))
+				}
+			},
+		},
+		{
+			name:   "questions reordered while edited on the server",
+			server: func(f *mergeFixture) { f.question("name").Title = "Full name" },
+			changes: func(f *mergeFixture) []models.FormPatchOperation {
+				return []models.FormPatchOperation{replaceOp("/question_order", f.order("name", "email", "rating"), f.order("rating", "name", "email"))}
+			},
+			applied: []string{"/question_order"},
+			check: func(t *testing.T, f *mergeFixture, _ *models.Form, questions []*models.Question) {
+				if !reflect.DeepEqual(questionIDs(questions), f.order("rating", "name", "email")) || questions[1].Title != "Full name" {
+					t.Errorf("questions = %v, want the editor order with the server edit", questionIDs(questions))
+				}
+				for i, q := range questions {
+					if q.Order != i+1 {
+						t.Errorf("question %d has order %d", i, q.Order)
+					}
+				}
+			},
+		},
+		{
+			name:   "questions reordered twice",
+			server: func(f *mergeFixture) { f.reorder("email", "name", "rating") },
+			changes: func(f *mergeFixture) []models.FormPatchOperation {
+				return []models.FormPatchOperation{replaceOp("/question_order", f.order("name", "email", "rating"), f.order("rating", "name", "email"))}
+			},
+			conflicts: []string{"/question_order reordered"},
+		},
+		{
+			name:   "questions reordered alike",
+			server: func(f *mergeFixture) { f.reorder("rating", "name", "email") },
+			changes: func(f *mergeFixture) []models.FormPatchOperation {
+				return []models.FormPatchOperation{replaceOp("/question_order", f.order("name", "email", "rating"), f.order("rating", "name", "email"))}
+			},
+		},
+		{
+			name:   "questions reordered while one is added on the server",
+			server: func(f *mergeFixture) { f.add("phone") },
+			changes: func(f *mergeFixture) []models.FormPatchOperation {
+				return []models.FormPatchOperation{replaceOp("/question_order", f.order("name", "email", "rating"), f.order("rating", "email", "name"))}
+			},
+			applied: []string{"/question_order"},
+			check: func(t *testing.T, f *mergeFixture, _ *models.Form, questions []*models.Question) {
+				if !reflect.DeepEqual(questionIDs(questions), f.order("rating", "email", "name", "phone")) {
+					t.Errorf("questions = %v, want the editor order then the added question", questionIDs(questions))
+				}
+			},
+		},
+		{
+			name:   "questions reordered while one is deleted on the server",
+			server: func(f *mergeFixture) { f.delete("email") },
+			changes: func(f *mergeFixture) []models.FormPatchOperation {
+				return []models.FormPatchOperation{replaceOp("/question_order", f.order("name", "email", "rating"), f.order("rating", "email", "name"))}
+			},
+			applied: []string{"/question_order"},
+			check: func(t *testing.T, f *mergeFixture, _ *models.Form, questions []*models.Question) {
+				if !reflect.DeepEqual(questionIDs(questions), f.order("rating", "name")) {
+					t.Errorf("questions = %v, want the editor order without the deleted question", questionIDs(questions))
+				}
+			},
+		},
+		{
+			name:   "question added and placed",
+			server: func(f *mergeFixture) { f.question("email").Title = "Email address" },
+			changes: func(f *mergeFixture) []models.FormPatchOperation {
+				f.ids["phone"] = uuid.New()
+				return []models.FormPatchOperation{
+					{Op: models.PatchAdd, Path: f.path("phone"), Value: encode(map[string]string{"type": "text", "title": "Phone"})},
+					replaceOp("/question_order", f.order("name", "email", "rating"), f.order("name", "phone", "email", "rating")),
+				}
+			},
+			applied: []string{"{phone}", "/question_order"},
+			check: func(t *testing.T, f *mergeFixture, _ *models.Form, questions []*models.Question) {
+				if !reflect.DeepEqual(questionIDs(questions), f.order("name", "phone", "email", "rating")) {
+					t.Errorf("questions = %v, want the added question second", questionIDs(questions))
+				}
+				if questions[1].Title != "Phone" || questions[1].ResultsVisibility != models.ResultsVisibilityPrivate || questions[2].Title != "Email address" {
+					t.Errorf("questions = %+v, want the added question and the server edit", questions)
+				}
+			},
+		},
+		{
+			name:   "questions added by both",
+			server: func(f *mergeFixture) { f.add("phone") },
+			changes: func(f *mergeFixture) []models.FormPatchOperation {
+				f.ids["age"] = uuid.New()
+				return []models.FormPatchOperation{{Op: models.PatchAdd, Path: f.path("age"), Value: encode(map[string]string{"type": "number", "title": "Age"})}}
+			},
+			applied: []string{"{age}"},
+			check: func(t *testing.T, f *mergeFixture, _ *models.Form, questions []*models.Question) {
+				if !reflect.DeepEqual(questionIDs(questions), f.order("name", "email", "rating", "phone", "age")) || questions[4].Order != 5 {
+					t.Errorf("questions = %v, want both added questions last", questionIDs(questions))
+				}
+			},
+		},
+
+		// Delete vs. edit
+		{
+			name:   "question edited while deleted on the server",
+			server: func(f *mergeFixture) { f.delete("email") },
+			changes: func(f *mergeFixture) []models.FormPatchOperation {
+				return []models.FormPatchOperation{replaceOp(f.path("email", "title"), "email", "Work email")}
+			},
+			conflicts: []string{"{email}/title deleted"},
+		},
+		{
+			name:   "question deleted while edited on the server",
+			server: func(f *mergeFixture) { f.question("email").Title = "Work email" },
+			changes: func(f *mergeFixture) []models.FormPatchOperation {
+				return []models.FormPatchOperation{{Op: models.PatchRemove, Path: f.path("email"), Base: baseQuestion("email")}}
+			},
+			conflicts: []string{"{email} edited"},
+		},
+		{
+			name:   "question deleted while another is edited on the server",
+			server: func(f *mergeFixture) { f.question("name").Title = "Full name" },
+			changes: func(f *mergeFixture) []models.FormPatchOperation {
+				return []models.FormPatchOperation{{Op: models.PatchRemove, Path: f.path("email"), Base: baseQuestion("email")}}
+			},
+			applied: []string{"{email}"},
+			check: func(t *testing.T, f *mergeFixture, _ *models.Form, questions []*models.Question) {
+				if !reflect.DeepEqual(questionIDs(questions), f.order("name", "rating")) || questions[0].Title != "Full name" {
+					t.Errorf("questions = %v, want the question deleted and the edit kept", questionIDs(questions))
+				}
+			},
+		},
+		{
+			name:   "question deleted by both",
+			server: func(f *mergeFixture) { f.delete("email") },
+			changes: func(f *mergeFixture) []models.FormPatchOperation {
+				return []models.FormPatchOperation{{Op: models.PatchRemove, Path: f.path("email"), Base: baseQuestion("email")}}
+			},
+		},
+		{
+			name:   "question deleted and edited by the editor while reordered on the server",
+			server: func(f *mergeFixture) { f.reorder("rating", "email", "name") },
+			changes: func(f *mergeFixture) []models.FormPatchOperation {
+				return []models.FormPatchOperation{
+					{Op: models.PatchRemove, Path: f.path("email"), Base: baseQuestion("email")},
+					replaceOp(f.path("email", "title"), "email", "Work email"),
+				}
+			},
+			applied:   []string{"{email}"},
+			conflicts: []string{"{email}/title deleted"},
+			check: func(t *testing.T, f *mergeFixture, _ *models.Form, questions []*models.Question) {
+				if !reflect.DeepEqual(questionIDs(questions), f.order("rating", "name")) {
+					t.Errorf("questions = %v, want the server order without the deleted question", questionIDs(questions))
+				}
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newMergeFixture()
+			if tc.server != nil {
+				tc.server(f)
+			}
+			changes := tc.changes(f)
+			doc, err := newFormDocument(f.form, f.questions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			applied, conflicts, err := doc.merge(changes)
+			if err != nil {
+				t.Fatalf("merge: %v", err)
+			}
+
+			names := make(map[string]string)
+			for key, id := range f.ids {
+				names["/questions/"+id.String()] = "{" + key + "}"
+			}
+			rename := func(path string) string {
+				for prefix, name := range names {
+					if len(path) >= len(prefix) && path[:len(prefix)] == prefix {
+						return name + path[len(prefix):]
+					}
+				}
+				return path
+			}
+			var gotApplied, gotConflicts []string
+			for _, op := range applied {
+				gotApplied = append(gotApplied, rename(op.Path))
+				if op.Base != nil {
+					t.Errorf("%s applied with its base", op.Path)
+				}
+			}
+			for _, c := range conflicts {
+				gotConflicts = append(gotConflicts, rename(c.Path)+" "+c.Reason)
+			}
+			if !reflect.DeepEqual(gotApplied, tc.applied) {
+				t.Errorf("applied = %v, want %v", gotApplied, tc.applied)
+			}
+			if !reflect.DeepEqual(gotConflicts, tc.conflicts) {
+				t.Errorf("conflicts = %v, want %v", gotConflicts, tc.conflicts)
+			}
+
+			_, form, questions, err := doc.build(f.form, f.questions)
+			if err != nil {
+				t.Fatalf("build: %v", err)
+			}
+			if tc.check != nil {
+				tc.check(t, f, form, questions)
+			}
+		})
+	}
+}
+
+func TestMergeConflictValues(t *testing.T) {
+	f := newMergeFixture()
+	f.form.Title = "Survey"
+	doc, _ := newFormDocument(f.form, f.questions)
+	_, conflicts, err := doc.merge([]models.FormPatchOperation{replaceOp("/title", "Feedback", "Product feedback")})
+	if err != nil || len(conflicts) != 1 {
+		t.Fatalf("conflicts = %v, %v; want one", conflicts, err)
+	}
+	want := MergeConflict{Path: "/title", Reason: ConflictEdited, Base: encode("Feedback"), Server: encode("Survey"), Client: encode("Product feedback")}
+	if !reflect.DeepEqual(conflicts[0], want) {
+		t.Errorf("conflict = %+v, want %+v", conflicts[0], want)
+	}
+}
+
+func TestMergeRejectsInvalidChanges(t *testing.T) {
+	f := newMergeFixture()
+	id := f.ids["name"].String()
+	for name, op := range map[string]models.FormPatchOperation{
+		"unknown path":            replaceOp("/status", "draft", "published"),
+		"unknown setting":         replaceOp("/settings/theme", nil, "dark"),
+		"unknown question field":  replaceOp("/questions/"+id+"/order", 1, 2),
+		"relative path":           replaceOp("title", "Feedback", "Survey"),
+		"question replaced":       replaceOp("/questions/"+id, nil, map[string]string{"title": "x"}),
+		"field added":             {Op: models.PatchAdd, Path: "/title", Value: encode("Survey")},
+		"question removed blind":  {Op: models.PatchRemove, Path: "/questions/" + id},
+		"question added as text":  {Op: models.PatchAdd, Path: "/questions/" + uuid.NewString(), Value: encode("Phone")},
+		"order with duplicates":   replaceOp("/question_order", f.order("name", "email", "rating"), f.order("name", "name", "rating")),
+		"order of something else": replaceOp("/question_order", f.order("name", "email", "rating"), "name"),
+	} {
+		doc, _ := newFormDocument(f.form, f.questions)
+		if _, _, err := doc.merge([]models.FormPatchOperation{op}); !errors.Is(err, ErrInvalidMerge) {
+			t.Errorf("%s: err = %v, want ErrInvalidMerge", name, err)
+		}
+	}
+
+	for name, op := range map[string]models.FormPatchOperation{
+		"title of the wrong type": replaceOp("/title", "Feedback", 42),
+		"empty title":             replaceOp("/title", "Feedback", ""),
+		"question without title":  replaceOp("/questions/"+id+"/title", "name", ""),
+		"invalid setting":         replaceOp("/settings/response_mode", nil, "whoever"),
+	} {
+		doc, _ := newFormDocument(f.form, f.questions)
+		applied, _, err := doc.merge([]models.FormPatchOperation{op})
+		if err != nil || len(applied) != 1 {
+			t.Fatalf("%s: merge = %v, %v", name, applied, err)
+		}
+		if _, _, _, err := doc.build(f.form, f.questions); !errors.Is(err, ErrInvalidMerge) && !errors.Is(err, ErrInvalidSettings) {
+			t.Errorf("%s: err = %v, want it rejected", name, err)
+		}
+	}
+}
+
+func questionIDs(questions []*models.Question) []uuid.UUID {
+	ids := make([]uuid.UUID, len(questions))
+	for i, q := range questions {
+		ids[i] = q.ID
+	}
+	return ids
+}
+
+// mergeForms applies merges to the forms and questions in memory. A number
+// of racing changes bump the version of the form before the merges that
+// follow are applied.
+type mergeForms struct {
+	*memoryFormRepository
+	questions *memoryQuestions
+	racing    int
+}
+
+func (r *mergeForms) ApplyMerge(_ context.Context, formID uuid.UUID, version int, merge repository.FormMerge) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	form := r.forms[formID]
+	if r.racing > 0 {
+		r.racing--
+		form.Version++
+		r.forms[formID] = form
+	}
+	if form.Version != version {
+		return 0, repository.ErrVersionChanged
+	}
+
+	if merge.Form != nil {
+		form = *merge.Form
+	}
+	form.Version = version + 1
+	r.forms[formID] = form
+	for _, q := range append(merge.Created, merge.Updated...) {
+		r.questions.questions[q.ID] = *q
+	}
+	for _, id := range merge.Deleted {
+		delete(r.questions.questions, id)
+	}
+	for _, qo := range merge.Order {
+		q := r.questions.questions[qo.ID]
+		q.Order = qo.Order
+		r.questions.questions[qo.ID] = q
+	}
+	return form.Version, nil
+}
+
+// recordingPatchPublisher records the activities and patches pushed to the
+// builders
+type recordingPatchPublisher struct {
+	mu         sync.Mutex
+	activities []*models.FormActivity
+	patches    []*models.FormPatch
+}
+
+func (p *recordingPatchPublisher) PublishActivity(_ context.Context, activity *models.FormActivity) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.activities = append(p.activities, activity)
+	return nil
+}
+
+func (p *recordingPatchPublisher) PublishPatch(_ context.Context, patch *models.FormPatch) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.patches = append(p.patches, patch)
+	return nil
+}
+
+func TestMergeForm(t *testing.T) {
+	ctx := context.Background()
+	repos := newMemoryStore(time.Now())
+	forms := &mergeForms{memoryFormRepository: repos.forms, questions: repos.questions}
+	publisher := &recordingPatchPublisher{}
+	svc := NewFormService(forms, repos.questions, nil, repos.orgs, events.LogPublisher{}, events.LogAuditor{}, nil, NewActivityLog(0, publisher), nil, nil)
+
+	owner := uuid.New()
+	form := repos.createForm(t, &models.Form{UserID: owner, Title: "Feedback", Status: models.FormStatusDraft})
+	name := &models.Question{ID: uuid.New(), FormID: form.ID, Type: models.QuestionTypeText, Title: "Name", Order: 1}
+	repos.createQuestions(t, name)
+
+	// Two editors autosave changes made on version 1
+	first, err := svc.MergeForm(ctx, form.ID, owner, MergeFormRequest{BaseVersion: 1, Changes: []models.FormPatchOperation{
+		replaceOp("/title", "Feedback", "Product feedback"),
+	}})
+	if err != nil {
+		t.Fatalf("first merge: %v", err)
+	}
+	if first.Version != 2 || first.Form.Title != "Product feedback" || len(first.Conflicts) != 0 {
+		t.Fatalf("first merge = version %d, %q, conflicts %v", first.Version, first.Form.Title, first.Conflicts)
+	}
+
+	second, err := svc.MergeForm(ctx, form.ID, owner, MergeFormRequest{BaseVersion: 1, Changes: []models.FormPatchOperation{
+		replaceOp("/title", "Feedback", "Survey"),
+		replaceOp("/description", "", "Tell us"),
+		replaceOp("/questions/"+name.ID.String()+"/title", "Name", "Full name"),
+	}})
+	if err != nil {
+		t.Fatalf("second merge: %v", err)
+	}
+	if second.Version != 3 || len(second.Applied) != 2 || len(second.Conflicts) != 1 || second.Conflicts[0].Path != "/title" {
+		t.Fatalf("second merge = version %d, applied %v, conflicts %v", second.Version, second.Applied, second.Conflicts)
+	}
+	if second.Form.Title != "Product feedback" || second.Form.Description != "Tell us" || second.Questions[0].Title != "Full name" {
+		t.Errorf("merged = %q, %q, %q", second.Form.Title, second.Form.Description, second.Questions[0].Title)
+	}
+	if stored := repos.questions.questions[name.ID]; stored.Title != "Full name" {
+		t.Errorf("stored question title = %q, want the merged one", stored.Title)
+	}
+
+	// Merges that apply nothing keep the version
+	noop, err := svc.MergeForm(ctx, form.ID, owner, MergeFormRequest{BaseVersion: 2, Changes: []models.FormPatchOperation{
+		replaceOp("/description", "", "Tell us"),
+	}})
+	if err != nil || noop.Version != 3 || len(noop.Applied) != 0 {
+		t.Errorf("merge of a change already made = %+v, %v; want version 3 and nothing applied", noop, err)
+	}
+
+	if len(publisher.patches) != 2 {
+		t.Fatalf("patches pushed = %d, want one per merge that applied changes", len(publisher.patches))
+	}
+	patch := publisher.patches[1]
+	if patch.PreviousVersion != 2 || patch.Version != 3 || len(patch.Operations) != 2 || patch.ActorID != owner {
+		t.Errorf("patch = %+v, want the 2 operations applied from version 2 to 3", patch)
+	}
+	activity := publisher.activities[len(publisher.activities)-1]
+	wantChanges := []string{"description", "questions/" + name.ID.String() + "/title"}
+	if activity.Action != models.ActivityFormMerged || !reflect.DeepEqual([]string(activity.Changes), wantChanges) {
+		t.Errorf("activity = %s %v, want form.merged %v", activity.Action, activity.Changes, wantChanges)
+	}
+
+	// A change racing the merge makes it start over, until it keeps racing
+	forms.racing = 1
+	retried, err := svc.MergeForm(ctx, form.ID, owner, MergeFormRequest{BaseVersion: 3, Changes: []models.FormPatchOperation{
+		replaceOp("/description", "Tell us", "Tell us more"),
+	}})
+	if err != nil || retried.Version != 5 {
+		t.Errorf("merge racing a change = %+v, %v; want it applied at version 5", retried, err)
+	}
+	forms.racing = mergeAttempts
+	_, err = svc.MergeForm(ctx, form.ID, owner, MergeFormRequest{BaseVersion: 5, Changes: []models.FormPatchOperation{
+		replaceOp("/description", "Tell us more", "Tell us"),
+	}})
+	if !errors.Is(err, ErrMergeContended) {
+		t.Errorf("merge racing changes: err = %v, want ErrMergeContended", err)
+	}
+
+	if _, err := svc.MergeForm(ctx, form.ID, owner, MergeFormRequest{BaseVersion: 99}); !errors.Is(err, ErrInvalidMerge) {
+		t.Errorf("base version ahead of the form: err = %v, want ErrInvalidMerge", err)
+	}
+	if _, err := svc.MergeForm(ctx, form.ID, uuid.New(), MergeFormRequest{BaseVersion: 1}); err == nil {
+		t.Error("merge by a stranger succeeded")
+	}
+}
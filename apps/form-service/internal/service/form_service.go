@@ -2,11 +2,23 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/access"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/challenge"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
 	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
 	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
 )
@@ -17,29 +29,114 @@ type FormService interface {
 	CreateForm(ctx context.Context, userID uuid.UUID, req CreateFormRequest) (*models.Form, error)
 	GetForm(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*models.Form, error)
 	GetUserForms(ctx context.Context, userID uuid.UUID, page, limit int) (*PaginatedFormsResponse, error)
+	GetSharedForms(ctx context.Context, userID uuid.UUID, page, limit int) (*PaginatedFormsResponse, error)
 	UpdateForm(ctx context.Context, id uuid.UUID, userID uuid.UUID, req UpdateFormRequest) (*models.Form, error)
 	DeleteForm(ctx context.Context, id uuid.UUID, userID uuid.UUID) error
-	PublishForm(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*models.Form, error)
+	PublishForm(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*models.Form, []string, error)
+	ResolveSlug(ctx context.Context, orgID uuid.UUID, slug string) (*ResolvedSlug, error)
+	GetPublishedForm(ctx context.Context, id uuid.UUID) (*PublishedForm, error)
+	CountForms(ctx context.Context) (int64, error)
+	ListFormChanges(ctx context.Context, userID uuid.UUID, cursor string, limit int) (*FormChangesResponse, error)
+
+	// Spam protection. SetThrottled serves the anomaly detector of the event
+	// bus, which throttles forms whose submissions surge.
+	GetProtectionStatus(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*ProtectionStatus, error)
+	GetThrottleState(ctx context.Context, id uuid.UUID) (*ProtectionStatus, error)
+	SetThrottled(ctx context.Context, id uuid.UUID, req SetThrottledRequest) (*ProtectionStatus, error)
+
+	// Translation operations
+	UpsertTranslation(ctx context.Context, formID uuid.UUID, userID uuid.UUID, locale string, req UpsertTranslationRequest) (*models.Form, error)
+	GetLocalizedForm(ctx context.Context, id uuid.UUID, userID uuid.UUID, locale string) (*LocalizedFormResponse, error)
 
 	// Question operations
 	AddQuestion(ctx context.Context, formID uuid.UUID, userID uuid.UUID, req AddQuestionRequest) (*models.Question, error)
 	UpdateQuestion(ctx context.Context, questionID uuid.UUID, userID uuid.UUID, req UpdateQuestionRequest) (*models.Question, error)
 	DeleteQuestion(ctx context.Context, questionID uuid.UUID, userID uuid.UUID) error
 	ReorderQuestions(ctx context.Context, formID uuid.UUID, userID uuid.UUID, req ReorderQuestionsRequest) error
+	RenameQuestionKey(ctx context.Context, formID, questionID, userID uuid.UUID, req RenameQuestionKeyRequest) (*models.Question, error)
+
+	// MergeForm merges the changes a builder made on a past version of a
+	// form, for autosaves racing other editors
+	MergeForm(ctx context.Context, id uuid.UUID, userID uuid.UUID, req MergeFormRequest) (*MergeFormResult, error)
+
+	// CheckResponse checks the answers of a response against the
+	// cross-field rules of the form, for the response service
+	CheckResponse(ctx context.Context, formID uuid.UUID, req CheckResponseRequest) (*CheckResponseResult, error)
+	// AuthorizeTestSubmission checks the user may submit test responses to
+	// the form, for the response service
+	AuthorizeTestSubmission(ctx context.Context, formID uuid.UUID, userID uuid.UUID) error
 }
 
+// ErrInvalidTranslation is returned when a locale or translation bundle is rejected
+var ErrInvalidTranslation = errors.New("invalid translation")
+
+// ErrInvalidCursor is returned when a change feed cursor cannot be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ErrInvalidSettings is returned when form settings are rejected
+var ErrInvalidSettings = errors.New("invalid form settings")
+
+// ErrInvalidRetention is returned when the response retention of a form is
+// out of bounds
+var ErrInvalidRetention = errors.New("invalid retention")
+
+// ErrInvalidResultsVisibility is returned when the results of a question
+// are made public while its type is not aggregatable
+var ErrInvalidResultsVisibility = errors.New("invalid results visibility")
+
+// ErrInvalidDisplay is returned when the display settings of a question do
+// not apply to its type
+var ErrInvalidDisplay = errors.New("invalid display settings")
+
+// ErrInvalidRules is returned when the cross-field rules of a form read
+// unknown questions or questions of the wrong type
+var ErrInvalidRules = errors.New("invalid form rules")
+
+var (
+	// ErrInvalidSlug is returned for slugs that are not URL safe or reserved
+	ErrInvalidSlug = errors.New("invalid slug")
+	// ErrSlugTaken is returned when another form of the organization has the
+	// slug
+	ErrSlugTaken = errors.New("slug is already taken in this organization")
+	// ErrSlugAmbiguous is returned when a slug is resolved without an
+	// organization and several organizations have it
+	ErrSlugAmbiguous = errors.New("slug is used by several organizations; pass organization_id")
+)
+
+const (
+	// DefaultFormChangesLimit and MaxFormChangesLimit bound a change feed page
+	DefaultFormChangesLimit = 100
+	MaxFormChangesLimit     = 500
+
+	// FormChangesSettleTime holds back the changes this recent from the feed.
+	// updated_at is set before a transaction commits, so a change can commit
+	// after a later one was already served; waiting for it to settle keeps
+	// the cursor from passing it.
+	FormChangesSettleTime = 5 * time.Second
+)
+
 // CreateFormRequest represents a request to create a form
 type CreateFormRequest struct {
 	Title       string              `json:"title" binding:"required,max=200"`
 	Description string              `json:"description" binding:"max=2000"`
+	Slug        string              `json:"slug,omitempty" example:"feedback"`
 	Settings    models.FormSettings `json:"settings"`
+	// RetentionDays purges the responses this many days after they are
+	// submitted; unset keeps them forever
+	RetentionDays *int `json:"retention_days,omitempty" binding:"omitempty,min=1,max=3650" example:"365"`
 }
 
-// UpdateFormRequest represents a request to update a form
+// UpdateFormRequest represents a request to update a form. An empty slug
+// removes it, as does a retention of 0 days.
 type UpdateFormRequest struct {
-	Title       *string              `json:"title,omitempty" binding:"omitempty,max=200"`
-	Description *string              `json:"description,omitempty" binding:"omitempty,max=2000"`
-	Settings    *models.FormSettings `json:"settings,omitempty"`
+	Title         *string              `json:"title,omitempty" binding:"omitempty,max=200"`
+	Description   *string              `json:"description,omitempty" binding:"omitempty,max=2000"`
+	Slug          *string              `json:"slug,omitempty" example:"feedback"`
+	Settings      *models.FormSettings `json:"settings,omitempty"`
+	RetentionDays *int                 `json:"retention_days,omitempty" binding:"omitempty,min=0,max=3650" example:"365"`
+	// Rules replaces the cross-field validation rules; an empty list
+	// removes them
+	Rules *[]models.FormRule `json:"rules,omitempty"`
 }
 
 // AddQuestionRequest represents a request to add a question
@@ -50,6 +147,12 @@ type AddQuestionRequest struct {
 	Order       int                 `json:"order"`
 	Options     interface{}         `json:"options,omitempty"`
 	Validation  interface{}         `json:"validation,omitempty"`
+	// ResultsVisibility defaults to private
+	ResultsVisibility models.ResultsVisibility `json:"results_visibility,omitempty" enums:"private,aggregate_public"`
+	// Display only applies to select, radio and checkbox questions
+	Display *models.QuestionDisplay `json:"display,omitempty"`
+	// Key is generated from the title when omitted
+	Key string `json:"key,omitempty" binding:"max=64" example:"age_group"`
 }
 
 // UpdateQuestionRequest represents a request to update a question
@@ -60,6 +163,12 @@ type UpdateQuestionRequest struct {
 	Order       *int                 `json:"order,omitempty"`
 	Options     interface{}          `json:"options,omitempty"`
 	Validation  interface{}          `json:"validation,omitempty"`
+	// ResultsVisibility is checked against the type the question ends up
+	// with, so that changing the type of a published question is rejected
+	ResultsVisibility *models.ResultsVisibility `json:"results_visibility,omitempty" enums:"private,aggregate_public"`
+	// Display replaces the display settings, checked against the type the
+	// question ends up with
+	Display *models.QuestionDisplay `json:"display,omitempty"`
 }
 
 // ReorderQuestionsRequest represents a request to reorder questions
@@ -73,6 +182,111 @@ type QuestionOrder struct {
 	Order int       `json:"order" binding:"min=0"`
 }
 
+// UpsertTranslationRequest represents a request to replace the translation bundle of one locale
+type UpsertTranslationRequest struct {
+	Title       string                                `json:"title"`
+	Description string                                `json:"description"`
+	Questions   map[string]models.QuestionTranslation `json:"questions"`
+	// Rules translates the messages of rules, keyed by rule ID
+	Rules map[string]string `json:"rules,omitempty"`
+}
+
+// LocalizedFormResponse represents a form resolved into a single locale
+type LocalizedFormResponse struct {
+	Form           *models.Form          `json:"form"`
+	Questions      []*models.Question    `json:"questions"`
+	Locale         string                `json:"locale"`
+	ResponsePolicy models.ResponsePolicy `json:"response_policy"`
+}
+
+// FormChange is an entry of the change feed: a form created or updated, or
+// the tombstone of a deleted form
+type FormChange struct {
+	ID        uuid.UUID `json:"id"`
+	Deleted   bool      `json:"deleted"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Form is the current form, omitted from tombstones
+	Form *models.Form `json:"form,omitempty"`
+}
+
+// FormChangesResponse is a page of the change feed. NextCursor resumes the
+// feed after the page, or at the same position when it is empty.
+type FormChangesResponse struct {
+	Changes    []FormChange `json:"changes"`
+	NextCursor string       `json:"next_cursor"`
+	HasMore    bool         `json:"has_more"`
+}
+
+// PublishedForm is the definition of a published form respondents fill in
+type PublishedForm struct {
+	Form           *models.Form          `json:"form"`
+	Questions      []*models.Question    `json:"questions"`
+	ResponsePolicy models.ResponsePolicy `json:"response_policy"`
+	// SpamProtection is the challenge submissions must pass, omitted for
+	// forms without spam protection
+	SpamProtection *models.SpamChallenge `json:"spam_protection,omitempty"`
+	// ShuffleSeed seeds the shuffle of the questions with randomized
+	// options, omitted when no question randomizes or the respondent is
+	// unknown
+	ShuffleSeed *uint32 `json:"shuffle_seed,omitempty"`
+	// ViewToken is the view token the funnel beacons of the respondent
+	// carry, omitted when the respondent is unknown
+	ViewToken string `json:"view_token,omitempty"`
+	// Settings are the decoded settings of Form
+	Settings models.FormSettings `json:"-"`
+	// ETag identifies the definition of the form and its questions, computed
+	// when the definition was read from the database
+	ETag string `json:"-"`
+}
+
+// RespondentShuffleSeed returns the shuffle seed of the respondent with the
+// token, or nil when no question randomizes its options or the token is
+// empty. The seed is derived from the form and the token, so the respondent
+// sees the same order on every load while other respondents see their own.
+func (p *PublishedForm) RespondentShuffleSeed(respondentToken string) *uint32 {
+	if respondentToken == "" {
+		return nil
+	}
+	for _, question := range p.Questions {
+		if display, err := question.GetDisplay(); err == nil && display.RandomizeOptions {
+			sum := sha256.Sum256([]byte(p.Form.ID.String() + "\x00" + respondentToken))
+			seed := binary.BigEndian.Uint32(sum[:4])
+			return &seed
+		}
+	}
+	return nil
+}
+
+// ProtectionStatus is the spam protection of a form and whether a surge of
+// submissions throttled it
+type ProtectionStatus struct {
+	FormID      uuid.UUID  `json:"form_id"`
+	Throttled   bool       `json:"throttled"`
+	ThrottledAt *time.Time `json:"throttled_at,omitempty"`
+	// SpamProtection is the configured challenge, EffectiveSpamProtection
+	// the one submissions must pass: captcha while throttled
+	SpamProtection          models.SpamProtection `json:"spam_protection" enums:"none,captcha,honeypot"`
+	EffectiveSpamProtection models.SpamProtection `json:"effective_spam_protection" enums:"none,captcha,honeypot"`
+	// Throttling are the thresholds of the form, with the defaults filled in
+	Throttling models.ThrottleSettings `json:"throttling"`
+}
+
+// SetThrottledRequest throttles or releases a form, with the submission rates
+// the decision was made on
+type SetThrottledRequest struct {
+	Throttled         bool    `json:"throttled"`
+	RatePerMinute     float64 `json:"rate_per_minute" example:"840"`
+	BaselinePerMinute float64 `json:"baseline_per_minute" example:"2.5"`
+}
+
+// ResolvedSlug is the published form a slug names, or the slug the form
+// moved to when the slug was replaced
+type ResolvedSlug struct {
+	*PublishedForm
+	// MovedTo is the current slug of the form the slug used to name
+	MovedTo string `json:"moved_to,omitempty"`
+}
+
 // PaginatedFormsResponse represents a paginated list of forms
 type PaginatedFormsResponse struct {
 	Forms      []*models.Form `json:"forms"`
@@ -86,91 +300,204 @@ type PaginatedFormsResponse struct {
 type formService struct {
 	formRepo     repository.FormRepository
 	questionRepo repository.QuestionRepository
+	guard        formGuard
+	publisher    events.Publisher
+	auditor      events.Auditor
+	challenges   *challenge.Issuer
+	activity     *ActivityLog
+	usage        *UsageMeter
+	definitions  *DefinitionCache
+	now          func() time.Time
 }
 
-// NewFormService creates a new form service instance
-func NewFormService(formRepo repository.FormRepository, questionRepo repository.QuestionRepository) FormService {
+// NewFormService creates a new form service instance. Collaborators of a
+// form are allowed what their role allows besides the owner, and requests
+// acting in an organization only see its forms. Publishes and deletes are
+// recorded to auditor. The definitions of published forms carry the spam
+// protection challenges issued by challenges, which may be nil. Changes to
+// the definition of forms are recorded to activity, which may be nil too.
+// Forms created are metered by usage, which enforces the active forms quota
+// of the organization and may be nil as well. The definitions of published
+// forms are served from definitions, and dropped from it as the forms
+// change; without it they are read from the database.
+func NewFormService(formRepo repository.FormRepository, questionRepo repository.QuestionRepository, collaboratorRepo repository.CollaboratorRepository, orgRepo repository.OrganizationRepository, publisher events.Publisher, auditor events.Auditor, challenges *challenge.Issuer, activity *ActivityLog, usage *UsageMeter, definitions *DefinitionCache) FormService {
 	return &formService{
 		formRepo:     formRepo,
 		questionRepo: questionRepo,
+		guard:        formGuard{forms: formRepo, collaborators: collaboratorRepo, orgs: organizationScope{orgs: orgRepo}},
+		publisher:    publisher,
+		auditor:      auditor,
+		challenges:   challenges,
+		activity:     activity,
+		usage:        usage,
+		definitions:  definitions,
+		now:          time.Now,
 	}
 }
 
-// CreateForm creates a new form
+// CreateForm creates a new form in the organization the request acts in, or
+// in the personal organization of the user
 func (s *formService) CreateForm(ctx context.Context, userID uuid.UUID, req CreateFormRequest) (*models.Form, error) {
-	form := &models.Form{
-		UserID:      userID,
-		Title:       req.Title,
-		Description: req.Description,
-		Status:      models.FormStatusDraft,
+	slug, err := normalizeSlug(req.Slug)
+	if err != nil {
+		return nil, err
 	}
 
-	// Convert FormSettings to JSON
-	if settingsJSON, err := json.Marshal(req.Settings); err == nil {
-		form.Settings = settingsJSON
+	orgID, err := s.guard.orgs.home(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.usage.checkActiveForms(ctx, orgID); err != nil {
+		return nil, err
 	}
 
-	if err := s.formRepo.Create(ctx, form); err != nil {
-		return nil, fmt.Errorf("failed to create form: %w", err)
+	settings, err := encodeSettings(req.Settings)
+	if err != nil {
+		return nil, err
 	}
 
-	return form, nil
-}
+	if req.RetentionDays != nil {
+		if err := models.ValidateRetentionDays(*req.RetentionDays); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRetention, err)
+		}
+	}
 
-// GetForm retrieves a form by ID with access control
-func (s *formService) GetForm(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*models.Form, error) {
-	form, err := s.formRepo.GetByID(ctx, id)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get form: %w", err)
+	form := &models.Form{
+		ID:             uuid.New(),
+		Version:        1,
+		UserID:         userID,
+		OrganizationID: orgID,
+		Title:          req.Title,
+		Description:    req.Description,
+		Slug:           slug,
+		Status:         models.FormStatusDraft,
+		Settings:       settings,
+		RetentionDays:  req.RetentionDays,
 	}
 
-	// Check if user has access to the form
-	if form.UserID != userID {
-		return nil, fmt.Errorf("access denied: user does not own this form")
+	err = s.activity.record(ctx, formActivity(form.ID, userID, models.ActivityFormCreated, nil), func(ctx context.Context) error {
+		return s.formRepo.Create(ctx, form)
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrSlugTaken) {
+			return nil, ErrSlugTaken
+		}
+		return nil, fmt.Errorf("failed to create form: %w", err)
 	}
+	s.usage.record(ctx, orgID, models.UsageFormsCreated, form.ID.String(), 1)
 
 	return form, nil
 }
 
+// GetForm retrieves a form by ID for its owner and collaborators
+func (s *formService) GetForm(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*models.Form, error) {
+	return s.guard.authorize(ctx, id, userID, access.View)
+}
+
 // GetUserForms retrieves forms for a user with pagination
 func (s *formService) GetUserForms(ctx context.Context, userID uuid.UUID, page, limit int) (*PaginatedFormsResponse, error) {
-	if page < 1 {
-		page = 1
+	page, limit = normalizePage(page, limit)
+	offset := (page - 1) * limit
+
+	orgID, scoped, err := s.guard.orgs.resolve(ctx, userID)
+	if err != nil {
+		return nil, err
 	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+
+	var forms []*models.Form
+	if scoped {
+		forms, err = s.formRepo.ListByOrganization(ctx, orgID, userID, limit, offset)
+	} else {
+		forms, err = s.formRepo.GetByUserID(ctx, userID, limit, offset)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user forms: %w", err)
 	}
 
+	var total int64
+	if scoped {
+		total, err = s.formRepo.CountByOrganization(ctx, orgID, userID)
+	} else {
+		total, err = s.formRepo.Count(ctx, userID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to count user forms: %w", err)
+	}
+
+	return paginate(forms, total, page, limit), nil
+}
+
+// GetSharedForms retrieves the forms a user collaborates on, with pagination
+func (s *formService) GetSharedForms(ctx context.Context, userID uuid.UUID, page, limit int) (*PaginatedFormsResponse, error) {
+	page, limit = normalizePage(page, limit)
 	offset := (page - 1) * limit
 
-	forms, err := s.formRepo.GetByUserID(ctx, userID, limit, offset)
+	orgID, scoped, err := s.guard.orgs.resolve(ctx, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user forms: %w", err)
+		return nil, err
 	}
 
-	total, err := s.formRepo.Count(ctx, userID)
+	var forms []*models.Form
+	if scoped {
+		forms, err = s.formRepo.ListSharedInOrganization(ctx, orgID, userID, limit, offset)
+	} else {
+		forms, err = s.formRepo.GetSharedWithUser(ctx, userID, limit, offset)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to count user forms: %w", err)
+		return nil, fmt.Errorf("failed to get shared forms: %w", err)
+	}
+
+	var total int64
+	if scoped {
+		total, err = s.formRepo.CountSharedInOrganization(ctx, orgID, userID)
+	} else {
+		total, err = s.formRepo.CountSharedWithUser(ctx, userID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to count shared forms: %w", err)
 	}
 
-	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	return paginate(forms, total, page, limit), nil
+}
 
+// normalizePage defaults the page to 1 and the limit to 10
+func normalizePage(page, limit int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+	return page, limit
+}
+
+func paginate(forms []*models.Form, total int64, page, limit int) *PaginatedFormsResponse {
 	return &PaginatedFormsResponse{
 		Forms:      forms,
 		Total:      total,
 		Page:       page,
 		Limit:      limit,
-		TotalPages: totalPages,
-	}, nil
+		TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+	}
 }
 
 // UpdateForm updates an existing form
 func (s *formService) UpdateForm(ctx context.Context, id uuid.UUID, userID uuid.UUID, req UpdateFormRequest) (*models.Form, error) {
-	form, err := s.GetForm(ctx, id, userID)
+	form, err := s.guard.authorize(ctx, id, userID, access.Edit)
 	if err != nil {
 		return nil, err
 	}
 
+	before := *form
+	previousSlug := slugOf(form)
+	if req.Slug != nil {
+		slug, err := normalizeSlug(*req.Slug)
+		if err != nil {
+			return nil, err
+		}
+		form.Slug = slug
+	}
+
 	// Update fields if provided
 	if req.Title != nil {
 		form.Title = *req.Title
@@ -179,22 +506,111 @@ func (s *formService) UpdateForm(ctx context.Context, id uuid.UUID, userID uuid.
 		form.Description = *req.Description
 	}
 	if req.Settings != nil {
-		// Convert FormSettings to JSON
-		if settingsJSON, err := json.Marshal(*req.Settings); err == nil {
-			form.Settings = settingsJSON
+		settings, err := encodeSettings(*req.Settings)
+		if err != nil {
+			return nil, err
+		}
+		form.Settings = settings
+	}
+	if req.RetentionDays != nil {
+		if *req.RetentionDays == 0 {
+			form.RetentionDays = nil
+		} else if err := models.ValidateRetentionDays(*req.RetentionDays); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRetention, err)
+		} else {
+			days := *req.RetentionDays
+			form.RetentionDays = &days
+		}
+	}
+	if req.Rules != nil {
+		questions, err := s.questionRepo.GetByFormID(ctx, form.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get questions: %w", err)
+		}
+		if err := models.ValidateRules(*req.Rules, questions); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRules, err)
+		}
+		if err := form.SetRules(*req.Rules); err != nil {
+			return nil, err
 		}
 	}
 
-	if err := s.formRepo.Update(ctx, form); err != nil {
+	activity := formActivity(form.ID, userID, models.ActivityFormUpdated, formChanges(&before, form))
+	err = s.activity.record(ctx, activity, func(ctx context.Context) error {
+		if slugOf(form) == previousSlug {
+			return s.formRepo.Update(ctx, form)
+		}
+		// Links shared with the previous slug keep working for a while
+		return s.formRepo.ChangeSlug(ctx, form, previousSlug, s.now().Add(models.SlugRedirectTTL))
+	})
+	if errors.Is(err, repository.ErrSlugTaken) {
+		return nil, ErrSlugTaken
+	}
+	if err != nil {
 		return nil, fmt.Errorf("failed to update form: %w", err)
 	}
+	s.publishFormChanged(ctx, events.FormUpdated, form, previousSlug)
 
 	return form, nil
 }
 
+// publishFormChanged tells the caches of a form that it changed, with the
+// slug it was resolved by before the change when that differs, and drops
+// its cached definition
+func (s *formService) publishFormChanged(ctx context.Context, eventType string, form *models.Form, previousSlug string) {
+	s.definitions.invalidate(ctx, form.ID)
+	data := map[string]interface{}{
+		"form_id":         form.ID.String(),
+		"organization_id": form.OrganizationID.String(),
+		"slug":            slugOf(form),
+		"changed_at":      s.now().UTC(),
+	}
+	if previousSlug != slugOf(form) {
+		data["previous_slug"] = previousSlug
+	}
+	if err := s.publisher.Publish(ctx, eventType, form.ID.String(), data); err != nil {
+		log.Printf("Failed to publish %s event for form %s: %v", eventType, form.ID, err)
+	}
+}
+
+// encodeSettings validates form settings, normalizing their embed origins,
+// and encodes them to JSON
+func encodeSettings(settings models.FormSettings) ([]byte, error) {
+	origins, err := models.NormalizeOrigins(settings.EmbedAllowedOrigins)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSettings, err)
+	}
+	settings.EmbedAllowedOrigins = origins
+	if err := settings.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSettings, err)
+	}
+	return json.Marshal(settings)
+}
+
+// normalizeSlug normalizes and validates a requested slug; an empty slug is
+// none
+func normalizeSlug(requested string) (*string, error) {
+	slug := models.NormalizeSlug(requested)
+	if slug == "" {
+		return nil, nil
+	}
+	if err := models.ValidateSlug(slug); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSlug, err)
+	}
+	return &slug, nil
+}
+
+// slugOf returns the slug of a form, empty when it has none
+func slugOf(form *models.Form) string {
+	if form.Slug == nil {
+		return ""
+	}
+	return *form.Slug
+}
+
 // DeleteForm deletes a form
 func (s *formService) DeleteForm(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
-	form, err := s.GetForm(ctx, id, userID)
+	form, err := s.guard.authorize(ctx, id, userID, access.Delete)
 	if err != nil {
 		return err
 	}
@@ -203,49 +619,578 @@ func (s *formService) DeleteForm(ctx context.Context, id uuid.UUID, userID uuid.
 		return fmt.Errorf("failed to delete form: %w", err)
 	}
 
+	s.auditor.Record(ctx, events.AuditEvent{
+		Action:       events.AuditFormDeleted,
+		Actor:        userID.String(),
+		ResourceType: "form",
+		ResourceID:   form.ID.String(),
+		Before:       formSummary(form),
+	})
+	s.publishFormChanged(ctx, events.FormDeleted, form, slugOf(form))
 	return nil
 }
 
-// PublishForm publishes a form
-func (s *formService) PublishForm(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*models.Form, error) {
-	form, err := s.GetForm(ctx, id, userID)
+// formSummary is what the audit log keeps of a form
+func formSummary(form *models.Form) map[string]interface{} {
+	return map[string]interface{}{
+		"title":           form.Title,
+		"status":          form.Status,
+		"owner_id":        form.UserID.String(),
+		"organization_id": form.OrganizationID.String(),
+	}
+}
+
+// CountForms returns the total number of forms for internal reporting
+func (s *formService) CountForms(ctx context.Context) (int64, error) {
+	count, err := s.formRepo.CountAll(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count forms: %w", err)
+	}
+	return count, nil
+}
+
+// ListFormChanges returns the forms of a user changed after cursor, oldest
+// first. Delivery is at least once: a form updated again shows again later in
+// the feed, and a page may repeat after a client retries with an old cursor.
+func (s *formService) ListFormChanges(ctx context.Context, userID uuid.UUID, cursor string, limit int) (*FormChangesResponse, error) {
+	after, err := decodeChangeCursor(cursor)
 	if err != nil {
 		return nil, err
 	}
+	if limit <= 0 {
+		limit = DefaultFormChangesLimit
+	} else if limit > MaxFormChangesLimit {
+		limit = MaxFormChangesLimit
+	}
+
+	orgID, scoped, err := s.guard.orgs.resolve(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// One more form than the page tells whether another page follows
+	until := s.now().Add(-FormChangesSettleTime)
+	var forms []*models.Form
+	if scoped {
+		forms, err = s.formRepo.ListChangesInOrganization(ctx, orgID, userID, after, until, limit+1)
+	} else {
+		forms, err = s.formRepo.ListChanges(ctx, userID, after, until, limit+1)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list form changes: %w", err)
+	}
+
+	response := &FormChangesResponse{
+		Changes:    make([]FormChange, 0, len(forms)),
+		NextCursor: cursor,
+		HasMore:    len(forms) > limit,
+	}
+	if response.HasMore {
+		forms = forms[:limit]
+	}
+	for _, form := range forms {
+		change := FormChange{ID: form.ID, Deleted: form.DeletedAt.Valid, UpdatedAt: form.UpdatedAt}
+		if !change.Deleted {
+			change.Form = form
+		}
+		response.Changes = append(response.Changes, change)
+	}
+	if len(forms) > 0 {
+		last := forms[len(forms)-1]
+		response.NextCursor = encodeChangeCursor(repository.ChangeCursor{UpdatedAt: last.UpdatedAt, ID: last.ID})
+	}
+
+	return response, nil
+}
+
+// encodeChangeCursor encodes a feed position into an opaque cursor
+func encodeChangeCursor(c repository.ChangeCursor) string {
+	raw := strconv.FormatInt(c.UpdatedAt.UnixMicro(), 10) + ":" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeChangeCursor decodes a cursor of encodeChangeCursor, the empty cursor
+// being the start of the feed
+func decodeChangeCursor(cursor string) (repository.ChangeCursor, error) {
+	if cursor == "" {
+		return repository.ChangeCursor{}, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return repository.ChangeCursor{}, ErrInvalidCursor
+	}
+	micros, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return repository.ChangeCursor{}, ErrInvalidCursor
+	}
+	usec, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return repository.ChangeCursor{}, ErrInvalidCursor
+	}
+	formID, err := uuid.Parse(id)
+	if err != nil {
+		return repository.ChangeCursor{}, ErrInvalidCursor
+	}
+	return repository.ChangeCursor{UpdatedAt: time.UnixMicro(usec).UTC(), ID: formID}, nil
+}
+
+// PublishForm publishes a form. Incomplete translations don't block publishing
+// but are reported back as warnings.
+func (s *formService) PublishForm(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*models.Form, []string, error) {
+	form, err := s.guard.authorize(ctx, id, userID, access.Publish)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	warnings, err := s.translationWarnings(ctx, form)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	if form.Status == models.FormStatusPublished {
-		return form, nil // Already published
+		return form, warnings, nil // Already published
 	}
 
+	before := formSummary(form)
 	form.Status = models.FormStatusPublished
 
-	if err := s.formRepo.Update(ctx, form); err != nil {
-		return nil, fmt.Errorf("failed to publish form: %w", err)
+	err = s.activity.record(ctx, formActivity(form.ID, userID, models.ActivityFormPublished, nil), func(ctx context.Context) error {
+		return s.formRepo.Update(ctx, form)
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to publish form: %w", err)
+	}
+	s.definitions.invalidate(ctx, form.ID)
+	s.auditor.Record(ctx, events.AuditEvent{
+		Action:       events.AuditFormPublished,
+		Actor:        userID.String(),
+		ResourceType: "form",
+		ResourceID:   form.ID.String(),
+		Before:       before,
+		After:        formSummary(form),
+	})
+
+	err = s.publisher.Publish(ctx, events.FormPublished, form.ID.String(), map[string]interface{}{
+		"form_id":         form.ID.String(),
+		"owner_id":        form.UserID.String(),
+		"organization_id": form.OrganizationID.String(),
+		"slug":            slugOf(form),
+		"title":           form.Title,
+		"published_at":    s.now().UTC(),
+	})
+	if err != nil {
+		log.Printf("Failed to publish %s event for form %s: %v", events.FormPublished, form.ID, err)
+	}
+
+	return form, warnings, nil
+}
+
+// ResolveSlug resolves a slug into the published form having it in the
+// organization, or in any organization for orgID uuid.Nil. Replaced slugs
+// resolve to the slug their form moved to until their redirect expires.
+// Drafts and closed forms are not found.
+func (s *formService) ResolveSlug(ctx context.Context, orgID uuid.UUID, slug string) (*ResolvedSlug, error) {
+	slug = models.NormalizeSlug(slug)
+	if models.ValidateSlug(slug) != nil {
+		return nil, ErrFormNotFound
+	}
+
+	forms, err := s.formRepo.ListBySlug(ctx, orgID, slug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve slug: %w", err)
+	}
+	if len(forms) == 0 {
+		return s.resolveRedirect(ctx, orgID, slug)
+	}
+	if len(forms) > 1 {
+		return nil, ErrSlugAmbiguous
+	}
+
+	published, err := s.published(ctx, forms[0])
+	if err != nil {
+		return nil, err
+	}
+	return &ResolvedSlug{PublishedForm: published}, nil
+}
+
+// GetPublishedForm retrieves the definition of a published form for
+// respondents. Drafts and closed forms are not found.
+func (s *formService) GetPublishedForm(ctx context.Context, id uuid.UUID) (*PublishedForm, error) {
+	definition, err := s.definitions.get(ctx, id, 0, func(ctx context.Context) (*formDefinition, error) {
+		form, err := s.formRepo.GetByID(ctx, id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrFormNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get form: %w", err)
+		}
+		return s.loadDefinition(ctx, form)
+	})
+	if err != nil {
+		return nil, err
 	}
+	return s.publishedDefinition(definition)
+}
+
+// published completes the definition of a form, which must be published,
+// from the cache when it holds the version of the form
+func (s *formService) published(ctx context.Context, form *models.Form) (*PublishedForm, error) {
+	if form.Status != models.FormStatusPublished {
+		return nil, ErrFormNotFound
+	}
+	definition, err := s.definitions.get(ctx, form.ID, form.Version, func(ctx context.Context) (*formDefinition, error) {
+		return s.loadDefinition(ctx, form)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.publishedDefinition(definition)
+}
+
+// loadDefinition reads the questions of a form, which must be published,
+// into its definition
+func (s *formService) loadDefinition(ctx context.Context, form *models.Form) (*formDefinition, error) {
+	if form.Status != models.FormStatusPublished {
+		return nil, ErrFormNotFound
+	}
+
+	questions, err := s.questionRepo.GetByFormID(ctx, form.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get questions: %w", err)
+	}
+	return newFormDefinition(form, questions)
+}
+
+// publishedDefinition completes a definition with what is issued on every
+// fetch: the spam protection challenge
+func (s *formService) publishedDefinition(definition *formDefinition) (*PublishedForm, error) {
+	form, questions := definition.models()
+	settings, err := effectiveSettings(form)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PublishedForm{
+		Form:           form,
+		Questions:      questions,
+		ResponsePolicy: settings.ResponsePolicy(),
+		SpamProtection: s.challenges.Issue(form.ID, settings),
+		Settings:       settings,
+		ETag:           definition.ETag,
+	}, nil
+}
+
+// effectiveSettings decodes the settings of the form as submissions are held
+// to them: throttled forms require the CAPTCHA challenge
+func effectiveSettings(form *models.Form) (models.FormSettings, error) {
+	settings, err := form.GetSettings()
+	if err != nil {
+		return settings, err
+	}
+	if form.ThrottledAt != nil {
+		settings.SpamProtection = models.SpamProtectionCaptcha
+	}
+	return settings, nil
+}
+
+// GetProtectionStatus retrieves the spam protection of a form the user can view
+func (s *formService) GetProtectionStatus(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*ProtectionStatus, error) {
+	form, err := s.guard.authorize(ctx, id, userID, access.View)
+	if err != nil {
+		return nil, err
+	}
+	return protectionStatus(form)
+}
+
+// GetThrottleState retrieves the spam protection of a form for the anomaly
+// detector
+func (s *formService) GetThrottleState(ctx context.Context, id uuid.UUID) (*ProtectionStatus, error) {
+	form, err := s.formRepo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrFormNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get form: %w", err)
+	}
+	return protectionStatus(form)
+}
+
+// SetThrottled throttles or releases a form. Changes are published as
+// form.throttle.engaged and form.throttle.released events, which the
+// notification worker emails the owner about.
+func (s *formService) SetThrottled(ctx context.Context, id uuid.UUID, req SetThrottledRequest) (*ProtectionStatus, error) {
+	form, err := s.formRepo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrFormNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get form: %w", err)
+	}
+
+	var at *time.Time
+	eventType := events.FormThrottleReleased
+	if req.Throttled {
+		now := s.now().UTC()
+		at, eventType = &now, events.FormThrottleEngaged
+	}
+	changed, err := s.formRepo.SetThrottled(ctx, id, at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to throttle form: %w", err)
+	}
+	if !changed {
+		return s.GetThrottleState(ctx, id)
+	}
+	form.ThrottledAt = at
+	// Throttling changes the challenge of the definition
+	s.definitions.invalidate(ctx, id)
+
+	err = s.publisher.Publish(ctx, eventType, form.ID.String(), map[string]interface{}{
+		"form_id":             form.ID.String(),
+		"owner_id":            form.UserID.String(),
+		"organization_id":     form.OrganizationID.String(),
+		"title":               form.Title,
+		"rate_per_minute":     req.RatePerMinute,
+		"baseline_per_minute": req.BaselinePerMinute,
+		"occurred_at":         s.now().UTC(),
+	})
+	if err != nil {
+		log.Printf("Failed to publish %s event for form %s: %v", eventType, form.ID, err)
+	}
+	return protectionStatus(form)
+}
+
+// protectionStatus summarizes the spam protection of the form
+func protectionStatus(form *models.Form) (*ProtectionStatus, error) {
+	configured, err := form.GetSettings()
+	if err != nil {
+		return nil, err
+	}
+	effective, err := effectiveSettings(form)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &ProtectionStatus{
+		FormID:                  form.ID,
+		Throttled:               form.ThrottledAt != nil,
+		ThrottledAt:             form.ThrottledAt,
+		SpamProtection:          configured.SpamProtection,
+		EffectiveSpamProtection: effective.SpamProtection,
+		Throttling:              configured.Throttling.Effective(),
+	}
+	if status.SpamProtection == "" {
+		status.SpamProtection = models.SpamProtectionNone
+	}
+	if status.EffectiveSpamProtection == "" {
+		status.EffectiveSpamProtection = models.SpamProtectionNone
+	}
+	return status, nil
+}
+
+// resolveRedirect resolves a slug no form has into the slug of the published
+// form it redirects to
+func (s *formService) resolveRedirect(ctx context.Context, orgID uuid.UUID, slug string) (*ResolvedSlug, error) {
+	redirects, err := s.formRepo.ListSlugRedirects(ctx, orgID, slug, s.now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve slug: %w", err)
+	}
+	if len(redirects) == 0 {
+		return nil, ErrFormNotFound
+	}
+	if len(redirects) > 1 {
+		return nil, ErrSlugAmbiguous
+	}
+
+	form, err := s.formRepo.GetByID(ctx, redirects[0].FormID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrFormNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get form: %w", err)
+	}
+	if form.Status != models.FormStatusPublished || form.Slug == nil {
+		return nil, ErrFormNotFound
+	}
+	return &ResolvedSlug{MovedTo: *form.Slug}, nil
+}
+
+// UpsertTranslation creates or replaces the translation bundle for a locale
+func (s *formService) UpsertTranslation(ctx context.Context, formID uuid.UUID, userID uuid.UUID, locale string, req UpsertTranslationRequest) (*models.Form, error) {
+	if !models.IsValidLocale(locale) {
+		return nil, fmt.Errorf("%w: unsupported locale %q", ErrInvalidTranslation, locale)
+	}
+	locale = models.NormalizeLocale(locale)
+
+	form, err := s.guard.authorize(ctx, formID, userID, access.Edit)
+	if err != nil {
+		return nil, err
+	}
+
+	if locale == form.GetDefaultLocale() {
+		return nil, fmt.Errorf("%w: %s is the default locale of this form", ErrInvalidTranslation, locale)
+	}
+
+	questions, err := s.questionRepo.GetByFormID(ctx, formID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get questions: %w", err)
+	}
+
+	bundle := models.TranslationBundle{
+		Title:       req.Title,
+		Description: req.Description,
+		Questions:   req.Questions,
+		Rules:       req.Rules,
+	}
+	if err := bundle.Validate(questions); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTranslation, err)
+	}
+	rules, err := form.GetRules()
+	if err != nil {
+		return nil, err
+	}
+	if err := bundle.ValidateRules(rules); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTranslation, err)
+	}
+
+	if err := form.SetTranslation(locale, bundle); err != nil {
+		return nil, err
+	}
+
+	activity := formActivity(form.ID, userID, models.ActivityTranslationUpdated, []string{"translations." + locale})
+	err = s.activity.record(ctx, activity, func(ctx context.Context) error {
+		return s.formRepo.Update(ctx, form)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save translation: %w", err)
+	}
+	s.publishFormChanged(ctx, events.FormUpdated, form, slugOf(form))
 
 	return form, nil
 }
 
+// GetLocalizedForm retrieves a form with its questions resolved into a single
+// locale, falling back to the default locale for missing strings
+func (s *formService) GetLocalizedForm(ctx context.Context, id uuid.UUID, userID uuid.UUID, locale string) (*LocalizedFormResponse, error) {
+	if !models.IsValidLocale(locale) {
+		return nil, fmt.Errorf("%w: unsupported locale %q", ErrInvalidTranslation, locale)
+	}
+
+	form, err := s.GetForm(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	questions, err := s.questionRepo.GetByFormID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get questions: %w", err)
+	}
+
+	translations, err := form.GetTranslations()
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := form.GetSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	bundle, resolved := models.ResolveLocale(translations, locale, form.GetDefaultLocale())
+	localizedForm, localizedQuestions := models.Localize(form, questions, bundle)
+
+	return &LocalizedFormResponse{
+		Form:           localizedForm,
+		Questions:      localizedQuestions,
+		Locale:         resolved,
+		ResponsePolicy: settings.ResponsePolicy(),
+	}, nil
+}
+
+// translationWarnings lists the untranslated strings of every locale of a form
+func (s *formService) translationWarnings(ctx context.Context, form *models.Form) ([]string, error) {
+	translations, err := form.GetTranslations()
+	if err != nil {
+		return nil, err
+	}
+	if len(translations) == 0 {
+		return []string{}, nil
+	}
+
+	questions, err := s.questionRepo.GetByFormID(ctx, form.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get questions: %w", err)
+	}
+
+	warnings := []string{}
+	for _, locale := range form.Locales() {
+		missing := translations[locale].MissingStrings(form, questions)
+		if len(missing) > 0 {
+			warnings = append(warnings, fmt.Sprintf("translation %s is incomplete: missing %s", locale, strings.Join(missing, ", ")))
+		}
+	}
+
+	return warnings, nil
+}
+
 // AddQuestion adds a new question to a form
 func (s *formService) AddQuestion(ctx context.Context, formID uuid.UUID, userID uuid.UUID, req AddQuestionRequest) (*models.Question, error) {
-	// Verify user owns the form
-	_, err := s.GetForm(ctx, formID, userID)
+	// Verify the user may edit the form
+	form, err := s.guard.authorize(ctx, formID, userID, access.Edit)
 	if err != nil {
 		return nil, err
 	}
 
 	question := &models.Question{
-		FormID:      formID,
-		Type:        req.Type,
-		Title:       req.Title,
-		Description: req.Description,
-		Order:       req.Order,
-		// TODO: Convert options and validation to proper JSONB types
+		ID:                uuid.New(),
+		FormID:            formID,
+		Type:              req.Type,
+		Key:               req.Key,
+		Title:             req.Title,
+		Description:       req.Description,
+		Order:             req.Order,
+		ResultsVisibility: req.ResultsVisibility,
+	}
+	if err := validateResultsVisibility(question); err != nil {
+		return nil, err
+	}
+	taken, err := takenQuestionKeys(ctx, s.questionRepo, formID, uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := assignQuestionKeys([]*models.Question{question}, taken); err != nil {
+		return nil, err
+	}
+	if req.Display != nil {
+		if question.Display, err = json.Marshal(req.Display); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateDisplay(question); err != nil {
+		return nil, err
 	}
 
-	if err := s.questionRepo.Create(ctx, question); err != nil {
+	if req.Options != nil {
+		if optionsJSON, err := json.Marshal(req.Options); err == nil {
+			question.Options = optionsJSON
+		}
+	}
+	if req.Validation != nil {
+		if validationJSON, err := json.Marshal(req.Validation); err == nil {
+			question.Validation = validationJSON
+		}
+	}
+
+	err = s.activity.record(ctx, questionActivity(question, userID, models.ActivityQuestionAdded, nil), func(ctx context.Context) error {
+		return s.questionRepo.Create(ctx, question)
+	})
+	if errors.Is(err, repository.ErrQuestionKeyTaken) {
+		return nil, ErrQuestionKeyTaken
+	}
+	if err != nil {
 		return nil, fmt.Errorf("failed to create question: %w", err)
 	}
+	s.publishFormChanged(ctx, events.FormUpdated, form, slugOf(form))
 
 	return question, nil
 }
@@ -257,12 +1202,14 @@ func (s *formService) UpdateQuestion(ctx context.Context, questionID uuid.UUID,
 		return nil, fmt.Errorf("failed to get question: %w", err)
 	}
 
-	// Verify user owns the form
-	_, err = s.GetForm(ctx, question.FormID, userID)
+	// Verify the user may edit the form
+	form, err := s.guard.authorize(ctx, question.FormID, userID, access.Edit)
 	if err != nil {
 		return nil, err
 	}
 
+	before := *question
+
 	// Update fields if provided
 	if req.Type != nil {
 		question.Type = *req.Type
@@ -276,14 +1223,79 @@ func (s *formService) UpdateQuestion(ctx context.Context, questionID uuid.UUID,
 	if req.Order != nil {
 		question.Order = *req.Order
 	}
+	if req.ResultsVisibility != nil {
+		question.ResultsVisibility = *req.ResultsVisibility
+	}
+	if err := validateResultsVisibility(question); err != nil {
+		return nil, err
+	}
+	if req.Display != nil {
+		if question.Display, err = json.Marshal(req.Display); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateDisplay(question); err != nil {
+		return nil, err
+	}
+	if req.Options != nil {
+		if optionsJSON, err := json.Marshal(req.Options); err == nil {
+			question.Options = optionsJSON
+		}
+	}
+	if req.Validation != nil {
+		if validationJSON, err := json.Marshal(req.Validation); err == nil {
+			question.Validation = validationJSON
+		}
+	}
+	if question.Type != before.Type {
+		// The rules reading the question must still accept its answers
+		if err := s.checkRulesWithout(ctx, form, question.ID, question); err != nil {
+			return nil, err
+		}
+	}
 
-	if err := s.questionRepo.Update(ctx, question); err != nil {
+	activity := questionActivity(question, userID, models.ActivityQuestionUpdated, questionChanges(&before, question))
+	err = s.activity.record(ctx, activity, func(ctx context.Context) error {
+		return s.questionRepo.Update(ctx, question)
+	})
+	if err != nil {
 		return nil, fmt.Errorf("failed to update question: %w", err)
 	}
+	s.publishFormChanged(ctx, events.FormUpdated, form, slugOf(form))
 
 	return question, nil
 }
 
+// validateResultsVisibility checks that only questions of aggregatable
+// types have public results
+func validateResultsVisibility(question *models.Question) error {
+	switch question.ResultsVisibility {
+	case "":
+		question.ResultsVisibility = models.ResultsVisibilityPrivate
+	case models.ResultsVisibilityPrivate:
+	case models.ResultsVisibilityAggregatePublic:
+		if !question.Type.Aggregatable() {
+			return fmt.Errorf("%w: only select, radio and checkbox questions can have aggregate_public results", ErrInvalidResultsVisibility)
+		}
+	default:
+		return fmt.Errorf("%w: must be private or aggregate_public", ErrInvalidResultsVisibility)
+	}
+	return nil
+}
+
+// validateDisplay checks that the display settings of a question apply to
+// its type
+func validateDisplay(question *models.Question) error {
+	display, err := question.GetDisplay()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidDisplay, err)
+	}
+	if err := display.Validate(question.Type); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidDisplay, err)
+	}
+	return nil
+}
+
 // DeleteQuestion deletes a question
 func (s *formService) DeleteQuestion(ctx context.Context, questionID uuid.UUID, userID uuid.UUID) error {
 	question, err := s.questionRepo.GetByID(ctx, questionID)
@@ -291,28 +1303,35 @@ func (s *formService) DeleteQuestion(ctx context.Context, questionID uuid.UUID,
 		return fmt.Errorf("failed to get question: %w", err)
 	}
 
-	// Verify user owns the form
-	_, err = s.GetForm(ctx, question.FormID, userID)
+	// Verify the user may edit the form
+	form, err := s.guard.authorize(ctx, question.FormID, userID, access.Edit)
 	if err != nil {
 		return err
 	}
+	if err := s.checkRulesWithout(ctx, form, questionID, nil); err != nil {
+		return err
+	}
 
-	if err := s.questionRepo.Delete(ctx, questionID); err != nil {
+	err = s.activity.record(ctx, questionActivity(question, userID, models.ActivityQuestionDeleted, nil), func(ctx context.Context) error {
+		return s.questionRepo.Delete(ctx, questionID)
+	})
+	if err != nil {
 		return fmt.Errorf("failed to delete question: %w", err)
 	}
+	s.publishFormChanged(ctx, events.FormUpdated, form, slugOf(form))
 
 	return nil
 }
 
 // ReorderQuestions reorders questions in a form
 func (s *formService) ReorderQuestions(ctx context.Context, formID uuid.UUID, userID uuid.UUID, req ReorderQuestionsRequest) error {
-	// Verify user owns the form
-	_, err := s.GetForm(ctx, formID, userID)
+	// Verify the user may edit the form
+	form, err := s.guard.authorize(ctx, formID, userID, access.Edit)
 	if err != nil {
 		return err
 	}
 
-	// Update each question's order
+	orders := make([]repository.QuestionOrder, 0, len(req.QuestionOrders))
 	for _, qo := range req.QuestionOrders {
 		question, err := s.questionRepo.GetByID(ctx, qo.ID)
 		if err != nil {
@@ -322,12 +1341,116 @@ func (s *formService) ReorderQuestions(ctx context.Context, formID uuid.UUID, us
 		if question.FormID != formID {
 			return fmt.Errorf("question %s does not belong to form %s", qo.ID, formID)
 		}
+		orders = append(orders, repository.QuestionOrder{ID: qo.ID, Order: qo.Order})
+	}
+
+	// The questions are reordered at once, recorded as one activity
+	err = s.activity.record(ctx, formActivity(formID, userID, models.ActivityQuestionsReordered, nil), func(ctx context.Context) error {
+		return s.questionRepo.UpdateOrder(ctx, formID, orders)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update question order: %w", err)
+	}
+	s.publishFormChanged(ctx, events.FormUpdated, form, slugOf(form))
+
+	return nil
+}
+
+// checkRulesWithout checks that the rules of a form still hold up once the
+// question is replaced, or removed when replacement is nil
+func (s *formService) checkRulesWithout(ctx context.Context, form *models.Form, questionID uuid.UUID, replacement *models.Question) error {
+	rules, err := form.GetRules()
+	if err != nil || len(rules) == 0 {
+		return err
+	}
+	questions, err := s.questionRepo.GetByFormID(ctx, form.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get questions: %w", err)
+	}
+
+	remaining := make([]*models.Question, 0, len(questions))
+	for _, q := range questions {
+		if q.ID != questionID {
+			remaining = append(remaining, q)
+		}
+	}
+	if replacement != nil {
+		remaining = append(remaining, replacement)
+	}
+	if err := models.ValidateRules(rules, remaining); err != nil {
+		return fmt.Errorf("%w: %v; change the rule first", ErrInvalidRules, err)
+	}
+	return nil
+}
+
+// CheckResponseRequest carries the answers of a response, keyed by question
+// ID, and the locale the respondent answered in
+type CheckResponseRequest struct {
+	Answers map[string]json.RawMessage `json:"answers" binding:"required" swaggertype:"object"`
+	Locale  string                     `json:"locale,omitempty" example:"de"`
+}
+
+// CheckResponseResult lists the rules a response breaks, with their messages
+// in the locale of the respondent
+type CheckResponseResult struct {
+	Valid  bool                   `json:"valid"`
+	Errors []models.RuleViolation `json:"errors"`
+}
+
+// CheckResponse checks the answers of a response against the cross-field
+// rules of the form
+func (s *formService) CheckResponse(ctx context.Context, formID uuid.UUID, req CheckResponseRequest) (*CheckResponseResult, error) {
+	form, err := s.formRepo.GetByID(ctx, formID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrFormNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get form: %w", err)
+	}
+	rules, err := form.GetRules()
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return &CheckResponseResult{Valid: true, Errors: []models.RuleViolation{}}, nil
+	}
 
-		question.Order = qo.Order
-		if err := s.questionRepo.Update(ctx, question); err != nil {
-			return fmt.Errorf("failed to update question order: %w", err)
+	questions, err := s.questionRepo.GetByFormID(ctx, formID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get questions: %w", err)
+	}
+	violations := models.CheckRules(rules, questions, req.Answers)
+
+	if req.Locale != "" && models.IsValidLocale(req.Locale) {
+		translations, err := form.GetTranslations()
+		if err != nil {
+			return nil, err
+		}
+		bundle, _ := models.ResolveLocale(translations, req.Locale, form.GetDefaultLocale())
+		for i, v := range violations {
+			if message := bundle.Rules[v.RuleID]; message != "" {
+				violations[i].Message = message
+			}
 		}
 	}
 
+	return &CheckResponseResult{Valid: len(violations) == 0, Errors: violations}, nil
+}
+
+// TestSubmissionRequest names the user submitting a test response
+type TestSubmissionRequest struct {
+	UserID uuid.UUID `json:"user_id" binding:"required"`
+}
+
+// AuthorizeTestSubmission allows the owner and the collaborators of a
+// published form to submit test responses to it
+func (s *formService) AuthorizeTestSubmission(ctx context.Context, formID uuid.UUID, userID uuid.UUID) error {
+	form, err := s.guard.authorize(ctx, formID, userID, access.SubmitTest)
+	if err != nil {
+		return err
+	}
+	if form.Status != models.FormStatusPublished {
+		return ErrFormNotFound
+	}
 	return nil
 }
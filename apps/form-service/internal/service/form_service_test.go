@@ -0,0 +1,617 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+)
+
+func newFeedService(t *testing.T) (*formService, *testClock) {
+	t.Helper()
+	repos := newMemoryStore(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	svc := repos.formService()
+	svc.now = repos.clock.now
+	return svc, repos.clock
+}
+
+// drain reads the feed from cursor to its end, returning the changes and the
+// cursor to resume from
+func drain(t *testing.T, svc *formService, userID uuid.UUID, cursor string, limit int) ([]FormChange, string) {
+	t.Helper()
+	var changes []FormChange
+	for {
+		page, err := svc.ListFormChanges(context.Background(), userID, cursor, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		changes = append(changes, page.Changes...)
+		cursor = page.NextCursor
+		if !page.HasMore {
+			return changes, cursor
+		}
+	}
+}
+
+func TestFormChangesFeed(t *testing.T) {
+	svc, clock := newFeedService(t)
+	ctx := context.Background()
+	owner, other := uuid.New(), uuid.New()
+
+	var created []*models.Form
+	for i := 0; i < 5; i++ {
+		form, err := svc.CreateForm(ctx, owner, CreateFormRequest{Title: "Form"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		created = append(created, form)
+		clock.advance(time.Second)
+	}
+	if _, err := svc.CreateForm(ctx, other, CreateFormRequest{Title: "Not mine"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Recent changes are held back until they settle
+	changes, cursor := drain(t, svc, owner, "", 2)
+	if len(changes) != 1 || changes[0].ID != created[0].ID {
+		t.Fatalf("changes = %+v, want only the settled first form", changes)
+	}
+
+	clock.advance(FormChangesSettleTime)
+	changes, cursor = drain(t, svc, owner, cursor, 2)
+	if len(changes) != 4 {
+		t.Fatalf("got %d changes, want the 4 other forms", len(changes))
+	}
+	for i, change := range changes {
+		if change.ID != created[i+1].ID || change.Deleted || change.Form == nil {
+			t.Errorf("change %d = %+v, want form %s", i, change, created[i+1].ID)
+		}
+	}
+
+	// An update and a deletion show after the cursor, the deletion as a tombstone
+	title := "Renamed"
+	if _, err := svc.UpdateForm(ctx, created[1].ID, owner, UpdateFormRequest{Title: &title}); err != nil {
+		t.Fatal(err)
+	}
+	clock.advance(time.Second)
+	if err := svc.DeleteForm(ctx, created[3].ID, owner); err != nil {
+		t.Fatal(err)
+	}
+	clock.advance(FormChangesSettleTime)
+
+	changes, next := drain(t, svc, owner, cursor, 10)
+	if len(changes) != 2 {
+		t.Fatalf("changes = %+v, want the update and the deletion", changes)
+	}
+	if changes[0].ID != created[1].ID || changes[0].Form.Title != "Renamed" {
+		t.Errorf("first change = %+v, want the renamed form", changes[0])
+	}
+	if changes[1].ID != created[3].ID || !changes[1].Deleted || changes[1].Form != nil {
+		t.Errorf("second change = %+v, want the tombstone of the deleted form", changes[1])
+	}
+
+	// An empty page keeps the cursor
+	page, err := svc.ListFormChanges(ctx, owner, next, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Changes) != 0 || page.HasMore || page.NextCursor != next {
+		t.Errorf("page = %+v, want empty at the same cursor", page)
+	}
+
+	// At least once: a retry with an old cursor repeats the page
+	again, _ := drain(t, svc, owner, cursor, 10)
+	if len(again) != 2 {
+		t.Errorf("retry returned %d changes, want 2", len(again))
+	}
+}
+
+func TestFormChangesLimitAndCursor(t *testing.T) {
+	svc, clock := newFeedService(t)
+	ctx := context.Background()
+	owner := uuid.New()
+	for i := 0; i < MaxFormChangesLimit+1; i++ {
+		if _, err := svc.CreateForm(ctx, owner, CreateFormRequest{Title: "Form"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	clock.advance(FormChangesSettleTime)
+
+	page, err := svc.ListFormChanges(ctx, owner, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Changes) != DefaultFormChangesLimit || !page.HasMore {
+		t.Errorf("default page has %d changes, has_more %v", len(page.Changes), page.HasMore)
+	}
+	page, err = svc.ListFormChanges(ctx, owner, "", 10*MaxFormChangesLimit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Changes) != MaxFormChangesLimit || !page.HasMore {
+		t.Errorf("capped page has %d changes, has_more %v", len(page.Changes), page.HasMore)
+	}
+
+	for _, cursor := range []string{"not base64!", "bm8tY29sb24", "YWJjOjEyMw"} {
+		if _, err := svc.ListFormChanges(ctx, owner, cursor, 10); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("cursor %q: err = %v, want ErrInvalidCursor", cursor, err)
+		}
+	}
+
+	at := repository.ChangeCursor{UpdatedAt: time.Date(2024, 3, 1, 12, 0, 0, 123456000, time.UTC), ID: uuid.New()}
+	decoded, err := decodeChangeCursor(encodeChangeCursor(at))
+	if err != nil || !decoded.UpdatedAt.Equal(at.UpdatedAt) || decoded.ID != at.ID {
+		t.Errorf("cursor round trip = %+v, %v; want %+v", decoded, err, at)
+	}
+}
+
+func TestFormSlugs(t *testing.T) {
+	ctx := context.Background()
+	repos := newMemoryStore(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	svc := repos.formService()
+	svc.now = repos.clock.now
+	owner, other := uuid.New(), uuid.New()
+
+	form, err := svc.CreateForm(ctx, owner, CreateFormRequest{Title: "Feedback", Slug: " Feedback "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slugOf(form) != "feedback" {
+		t.Errorf("slug = %q, want it normalized", slugOf(form))
+	}
+
+	for _, slug := range []string{"api", "a", "spaces here", "double--hyphen", "-edge"} {
+		if _, err := svc.CreateForm(ctx, owner, CreateFormRequest{Title: "Rejected", Slug: slug}); !errors.Is(err, ErrInvalidSlug) {
+			t.Errorf("slug %q: err = %v, want ErrInvalidSlug", slug, err)
+		}
+	}
+	if _, err := svc.CreateForm(ctx, owner, CreateFormRequest{Title: "Copy", Slug: "feedback"}); !errors.Is(err, ErrSlugTaken) {
+		t.Errorf("same slug in the same organization: err = %v, want ErrSlugTaken", err)
+	}
+
+	resolve := func(slug string) (*ResolvedSlug, error) {
+		return svc.ResolveSlug(ctx, uuid.Nil, slug)
+	}
+	if _, err := resolve("feedback"); !errors.Is(err, ErrFormNotFound) {
+		t.Errorf("draft: err = %v, want ErrFormNotFound", err)
+	}
+	if _, _, err := svc.PublishForm(ctx, form.ID, owner); err != nil {
+		t.Fatal(err)
+	}
+	if resolved, err := resolve("feedback"); err != nil || resolved.Form.ID != form.ID {
+		t.Errorf("published: %+v, %v; want the form", resolved, err)
+	}
+
+	renamed := "customer-feedback"
+	if _, err := svc.UpdateForm(ctx, form.ID, owner, UpdateFormRequest{Slug: &renamed}); err != nil {
+		t.Fatal(err)
+	}
+	if resolved, err := resolve("feedback"); err != nil || resolved.MovedTo != renamed {
+		t.Errorf("replaced slug: %+v, %v; want a redirect to %s", resolved, err, renamed)
+	}
+	repos.clock.advance(models.SlugRedirectTTL)
+	if _, err := resolve("feedback"); !errors.Is(err, ErrFormNotFound) {
+		t.Errorf("expired redirect: err = %v, want ErrFormNotFound", err)
+	}
+
+	// Slugs are unique within an organization only
+	theirs, err := svc.CreateForm(ctx, other, CreateFormRequest{Title: "Theirs", Slug: renamed})
+	if err != nil {
+		t.Fatalf("same slug in another organization: %v", err)
+	}
+	if _, _, err := svc.PublishForm(ctx, theirs.ID, other); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := resolve(renamed); !errors.Is(err, ErrSlugAmbiguous) {
+		t.Errorf("slug of two organizations: err = %v, want ErrSlugAmbiguous", err)
+	}
+	if resolved, err := svc.ResolveSlug(ctx, theirs.OrganizationID, renamed); err != nil || resolved.Form.ID != theirs.ID {
+		t.Errorf("slug resolved in an organization: %+v, %v; want their form", resolved, err)
+	}
+}
+
+func TestEmbedAllowedOrigins(t *testing.T) {
+	ctx := context.Background()
+	svc := newMemoryStore(time.Now()).formService()
+	owner := uuid.New()
+
+	form, err := svc.CreateForm(ctx, owner, CreateFormRequest{Title: "Embedded", Settings: models.FormSettings{
+		EmbedAllowedOrigins: []string{"https://WWW.Acme.com/", "https://www.acme.com", "http://localhost:3000"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings, _ := form.GetSettings()
+	if want := []string{"https://www.acme.com", "http://localhost:3000"}; !reflect.DeepEqual(settings.EmbedAllowedOrigins, want) {
+		t.Errorf("origins = %v, want them normalized to %v", settings.EmbedAllowedOrigins, want)
+	}
+
+	for _, origin := range []string{"www.acme.com", "ftp://acme.com", "https://acme.com/forms", "https://user@acme.com"} {
+		update := UpdateFormRequest{Settings: &models.FormSettings{EmbedAllowedOrigins: []string{origin}}}
+		if _, err := svc.UpdateForm(ctx, form.ID, owner, update); !errors.Is(err, ErrInvalidSettings) {
+			t.Errorf("origin %q: err = %v, want ErrInvalidSettings", origin, err)
+		}
+	}
+}
+
+func TestFormRetention(t *testing.T) {
+	ctx := context.Background()
+	svc := newMemoryStore(time.Now()).formService()
+	owner := uuid.New()
+	days := func(n int) *int { return &n }
+
+	form, err := svc.CreateForm(ctx, owner, CreateFormRequest{Title: "Kept a year", RetentionDays: days(365)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if form.RetentionDays == nil || *form.RetentionDays != 365 {
+		t.Errorf("retention = %v, want 365 days", form.RetentionDays)
+	}
+
+	for _, n := range []int{-1, 0, models.MaxRetentionDays + 1} {
+		if _, err := svc.CreateForm(ctx, owner, CreateFormRequest{Title: "Rejected", RetentionDays: days(n)}); !errors.Is(err, ErrInvalidRetention) {
+			t.Errorf("create with %d days: err = %v, want ErrInvalidRetention", n, err)
+		}
+	}
+	if _, err := svc.UpdateForm(ctx, form.ID, owner, UpdateFormRequest{RetentionDays: days(-1)}); !errors.Is(err, ErrInvalidRetention) {
+		t.Errorf("update with -1 days: err = %v, want ErrInvalidRetention", err)
+	}
+
+	// Updates without a retention keep it, and 0 days removes it
+	title := "Renamed"
+	if form, err = svc.UpdateForm(ctx, form.ID, owner, UpdateFormRequest{Title: &title}); err != nil || form.RetentionDays == nil {
+		t.Fatalf("update of the title: %+v, %v; want the retention kept", form, err)
+	}
+	if form, err = svc.UpdateForm(ctx, form.ID, owner, UpdateFormRequest{RetentionDays: days(0)}); err != nil || form.RetentionDays != nil {
+		t.Errorf("retention of 0 days: %v, %v; want it removed", form.RetentionDays, err)
+	}
+}
+
+func TestFormRules(t *testing.T) {
+	ctx := context.Background()
+	repos := newMemoryStore(time.Now())
+	owner := uuid.New()
+	form := repos.createForm(t, &models.Form{UserID: owner, Title: "Booking"})
+	svc := repos.formService()
+
+	ids := make(map[string]uuid.UUID)
+	for _, q := range []struct {
+		key string
+		typ models.QuestionType
+	}{
+		{"checkin", models.QuestionTypeText}, {"checkout", models.QuestionTypeText},
+		{"adults", models.QuestionTypeNumber}, {"children", models.QuestionTypeNumber},
+		{"phone", models.QuestionTypeText}, {"email", models.QuestionTypeEmail},
+		{"extras", models.QuestionTypeCheckbox}, {"notes", models.QuestionTypeTextarea},
+	} {
+		question := &models.Question{FormID: form.ID, Type: q.typ, Title: q.key}
+		repos.questions.Create(ctx, question)
+		ids[q.key] = question.ID
+	}
+	keys := func(names ...string) map[string]uuid.UUID {
+		m := make(map[string]uuid.UUID)
+		for _, name := range names {
+			m[name] = ids[name]
+		}
+		return m
+	}
+	total := 4.0
+	rules := []models.FormRule{
+		{ID: "stay", Type: models.FormRuleComparison, Questions: keys("checkin", "checkout"),
+			Left: "checkout", Operator: ">", Right: "checkin", Message: "Check-out must be after check-in"},
+		{ID: "guests", Type: models.FormRuleSumEquals, Questions: keys("adults", "children"), Value: &total, Message: "Rooms sleep 4 guests"},
+		{ID: "contact", Type: models.FormRuleAtLeast, Questions: keys("phone", "email"), Count: 1, Message: "Leave a phone number or an email"},
+		{Type: models.FormRuleExpression, Questions: keys("extras", "adults"),
+			Expression: `count(extras) <= adults || !answered(adults)`, Message: "One extra per adult"},
+	}
+
+	for name, invalid := range map[string]models.FormRule{
+		"mixed types":      {Type: models.FormRuleComparison, Questions: keys("adults", "checkin"), Left: "adults", Operator: "<", Right: "checkin", Message: "m"},
+		"unknown question": {Type: models.FormRuleAtLeast, Questions: map[string]uuid.UUID{"ghost": uuid.New()}, Count: 1, Message: "m"},
+		"text in a sum":    {Type: models.FormRuleSumEquals, Questions: keys("adults", "notes"), Value: &total, Message: "m"},
+		"bad expression":   {Type: models.FormRuleExpression, Questions: keys("adults"), Expression: `adults > `, Message: "m"},
+		"untyped":          {Type: models.FormRuleExpression, Questions: keys("adults", "notes"), Expression: `adults > notes`, Message: "m"},
+		"no message":       {Type: models.FormRuleAtLeast, Questions: keys("phone"), Count: 1},
+	} {
+		if _, err := svc.UpdateForm(ctx, form.ID, owner, UpdateFormRequest{Rules: &[]models.FormRule{invalid}}); !errors.Is(err, ErrInvalidRules) {
+			t.Errorf("%s: err = %v, want ErrInvalidRules", name, err)
+		}
+	}
+
+	updated, err := svc.UpdateForm(ctx, form.ID, owner, UpdateFormRequest{Rules: &rules})
+	if err != nil {
+		t.Fatalf("UpdateForm: %v", err)
+	}
+	saved, _ := updated.GetRules()
+	if len(saved) != 4 || saved[3].ID != "rule_4" {
+		t.Fatalf("rules = %+v, want the 4 rules with an ID given to the last", saved)
+	}
+	_, err = svc.UpsertTranslation(ctx, form.ID, owner, "de", UpsertTranslationRequest{Rules: map[string]string{"stay": "Die Abreise muss nach der Anreise liegen"}})
+	if err != nil {
+		t.Fatalf("UpsertTranslation: %v", err)
+	}
+	if _, err := svc.UpsertTranslation(ctx, form.ID, owner, "fr", UpsertTranslationRequest{Rules: map[string]string{"ghost": "m"}}); !errors.Is(err, ErrInvalidTranslation) {
+		t.Errorf("translation of an unknown rule: err = %v, want ErrInvalidTranslation", err)
+	}
+
+	answers := func(pairs ...string) map[string]json.RawMessage {
+		m := make(map[string]json.RawMessage)
+		for i := 0; i < len(pairs); i += 2 {
+			m[ids[pairs[i]].String()] = json.RawMessage(pairs[i+1])
+		}
+		return m
+	}
+	result, err := svc.CheckResponse(ctx, form.ID, CheckResponseRequest{Locale: "de-AT", Answers: answers(
+		"checkin", `"2024-05-03"`, "checkout", `"2024-05-01"`, "adults", `"3"`, "children", `2`,
+		"phone", `""`, "extras", `["breakfast","parking"]`)})
+	if err != nil {
+		t.Fatalf("CheckResponse: %v", err)
+	}
+	var broken []string
+	for _, v := range result.Errors {
+		broken = append(broken, v.RuleID+": "+v.Message)
+	}
+	want := []string{"stay: Die Abreise muss nach der Anreise liegen", "guests: Rooms sleep 4 guests", "contact: Leave a phone number or an email"}
+	if result.Valid || !reflect.DeepEqual(broken, want) {
+		t.Errorf("broken rules = %q, want %q", broken, want)
+	}
+
+	// Rules reading unanswered questions hold
+	result, err = svc.CheckResponse(ctx, form.ID, CheckResponseRequest{Answers: answers(
+		"checkin", `"2024-05-01"`, "adults", `3.5`, "children", `0.5`, "email", `"ada@example.com"`, "extras", `["breakfast"]`)})
+	if err != nil || !result.Valid {
+		t.Errorf("valid response: %+v, %v", result, err)
+	}
+
+	// Questions rules read can't change type or be deleted from under them
+	text := models.QuestionTypeText
+	if _, err := svc.UpdateQuestion(ctx, ids["adults"], owner, UpdateQuestionRequest{Type: &text}); !errors.Is(err, ErrInvalidRules) {
+		t.Errorf("retyping a question of a sum: err = %v, want ErrInvalidRules", err)
+	}
+	if err := svc.DeleteQuestion(ctx, ids["email"], owner); !errors.Is(err, ErrInvalidRules) {
+		t.Errorf("deleting a question of a rule: err = %v, want ErrInvalidRules", err)
+	}
+	if err := svc.DeleteQuestion(ctx, ids["notes"], owner); err != nil {
+		t.Errorf("deleting a question no rule reads: %v", err)
+	}
+}
+
+func TestPublishTranslationWarnings(t *testing.T) {
+	ctx := context.Background()
+	repos := newMemoryStore(time.Now())
+	svc := repos.formService()
+	owner := uuid.New()
+
+	tests := []struct {
+		name         string
+		translations map[string]func(questionID string) UpsertTranslationRequest
+		want         func(questionID string) []string
+	}{
+		{
+			name: "no translations",
+			want: func(string) []string { return []string{} },
+		},
+		{
+			name: "complete translation",
+			translations: map[string]func(string) UpsertTranslationRequest{
+				"de": func(id string) UpsertTranslationRequest {
+					return UpsertTranslationRequest{Title: "Umfrage", Description: "Über Sie", Questions: map[string]models.QuestionTranslation{
+						id: {Title: "Farbe", Options: map[string]string{"r": "Rot", "g": "Grün"}},
+					}}
+				},
+			},
+			want: func(string) []string { return []string{} },
+		},
+		{
+			name: "incomplete translations in locale order",
+			translations: map[string]func(string) UpsertTranslationRequest{
+				"fr": func(id string) UpsertTranslationRequest {
+					return UpsertTranslationRequest{Title: "Enquête", Questions: map[string]models.QuestionTranslation{id: {Title: "Couleur"}}}
+				},
+				"de": func(id string) UpsertTranslationRequest {
+					return UpsertTranslationRequest{Title: "Umfrage", Description: "Über Sie", Questions: map[string]models.QuestionTranslation{
+						id: {Title: "Farbe", Options: map[string]string{"r": "Rot", "g": "Grün"}},
+					}}
+				},
+				"es": func(string) UpsertTranslationRequest { return UpsertTranslationRequest{Title: "Encuesta"} },
+			},
+			want: func(id string) []string {
+				return []string{
+					"translation es is incomplete: missing description, questions." + id + ".title, questions." + id + ".options.r, questions." + id + ".options.g",
+					"translation fr is incomplete: missing description, questions." + id + ".options.r, questions." + id + ".options.g",
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := repos.createForm(t, &models.Form{UserID: owner, Title: "Survey", Description: "About you"})
+			question := &models.Question{FormID: form.ID, Type: models.QuestionTypeRadio, Title: "Colour",
+				Options: []byte(`[{"key":"r","label":"Red"},{"key":"g","label":"Green"}]`)}
+			repos.createQuestions(t, question)
+			for locale, translation := range tt.translations {
+				if _, err := svc.UpsertTranslation(ctx, form.ID, owner, locale, translation(question.ID.String())); err != nil {
+					t.Fatalf("UpsertTranslation(%s): %v", locale, err)
+				}
+			}
+
+			want := tt.want(question.ID.String())
+			published, warnings, err := svc.PublishForm(ctx, form.ID, owner)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if published.Status != models.FormStatusPublished || !reflect.DeepEqual(warnings, want) {
+				t.Errorf("PublishForm() = %s with warnings %q, want published with %q", published.Status, warnings, want)
+			}
+			if _, warnings, err := svc.PublishForm(ctx, form.ID, owner); err != nil || !reflect.DeepEqual(warnings, want) {
+				t.Errorf("publishing again: warnings %q (err %v), want %q", warnings, err, want)
+			}
+		})
+	}
+}
+
+func TestFormThrottling(t *testing.T) {
+	ctx := context.Background()
+	publisher := &recordingPublisher{}
+	repos := newMemoryStore(time.Now())
+	svc := NewFormService(repos.forms, repos.questions, nil, repos.orgs, publisher, events.LogAuditor{}, nil, nil, nil, nil)
+	owner := uuid.New()
+
+	form, err := svc.CreateForm(ctx, owner, CreateFormRequest{Title: "Giveaway", Settings: models.FormSettings{
+		SpamProtection: models.SpamProtectionHoneypot,
+		Throttling:     models.ThrottleSettings{Multiplier: 20},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := svc.PublishForm(ctx, form.ID, owner); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := svc.GetProtectionStatus(ctx, form.ID, owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := models.ThrottleSettings{Multiplier: 20, MinRatePerMinute: 30, IPLimitPerMinute: 3}
+	if status.Throttled || status.EffectiveSpamProtection != models.SpamProtectionHoneypot || status.Throttling != want {
+		t.Errorf("status = %+v, want honeypot unthrottled with thresholds %+v", status, want)
+	}
+
+	engage := SetThrottledRequest{Throttled: true, RatePerMinute: 840, BaselinePerMinute: 2.5}
+	for i := 0; i < 2; i++ {
+		if status, err = svc.SetThrottled(ctx, form.ID, engage); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !status.Throttled || status.ThrottledAt == nil || status.SpamProtection != models.SpamProtectionHoneypot ||
+		status.EffectiveSpamProtection != models.SpamProtectionCaptcha {
+		t.Errorf("throttled status = %+v, want captcha required over honeypot", status)
+	}
+	if published, err := svc.GetPublishedForm(ctx, form.ID); err != nil || published.Settings.SpamProtection != models.SpamProtectionCaptcha {
+		t.Errorf("published form of a throttled form: %v, want captcha required", err)
+	}
+
+	if status, err = svc.SetThrottled(ctx, form.ID, SetThrottledRequest{RatePerMinute: 10, BaselinePerMinute: 2.5}); err != nil {
+		t.Fatal(err)
+	}
+	if status.Throttled || status.EffectiveSpamProtection != models.SpamProtectionHoneypot {
+		t.Errorf("released status = %+v, want honeypot unthrottled", status)
+	}
+
+	var throttleEvents []string
+	for _, eventType := range publisher.events {
+		if eventType == events.FormThrottleEngaged || eventType == events.FormThrottleReleased {
+			throttleEvents = append(throttleEvents, eventType)
+		}
+	}
+	if want := []string{events.FormThrottleEngaged, events.FormThrottleReleased}; !reflect.DeepEqual(throttleEvents, want) {
+		t.Errorf("events = %v, want one per change %v", throttleEvents, want)
+	}
+
+	if _, err := svc.GetProtectionStatus(ctx, form.ID, uuid.New()); !errors.Is(err, ErrNotFormOwner) {
+		t.Errorf("status for a stranger: err = %v, want ErrNotFormOwner", err)
+	}
+}
+
+func TestFormChangeEvents(t *testing.T) {
+	ctx := context.Background()
+	publisher := &recordingPublisher{}
+	repos := newMemoryStore(time.Now())
+	svc := NewFormService(repos.forms, repos.questions, nil, repos.orgs, publisher, events.LogAuditor{}, nil, nil, nil, nil)
+	owner := uuid.New()
+
+	form, err := svc.CreateForm(ctx, owner, CreateFormRequest{Title: "Survey", Slug: "survey"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	title := "Customer survey"
+	if _, err := svc.UpdateForm(ctx, form.ID, owner, UpdateFormRequest{Title: &title}); err != nil {
+		t.Fatal(err)
+	}
+	slug := "customer-survey"
+	if _, err := svc.UpdateForm(ctx, form.ID, owner, UpdateFormRequest{Slug: &slug}); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.DeleteForm(ctx, form.ID, owner); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{events.FormUpdated, events.FormUpdated, events.FormDeleted}; !reflect.DeepEqual(publisher.events, want) {
+		t.Fatalf("events = %v, want %v", publisher.events, want)
+	}
+	for i, key := range publisher.keys {
+		if key != form.ID.String() || publisher.data[i]["form_id"] != form.ID.String() {
+			t.Errorf("event %d keyed %s, want the form", i, key)
+		}
+	}
+	if _, ok := publisher.data[0]["previous_slug"]; ok || publisher.data[0]["slug"] != "survey" {
+		t.Errorf("title change = %v, want slug survey without a previous slug", publisher.data[0])
+	}
+	if publisher.data[1]["slug"] != "customer-survey" || publisher.data[1]["previous_slug"] != "survey" {
+		t.Errorf("slug change = %v, want the previous slug to be purged too", publisher.data[1])
+	}
+}
+
+func TestQuestionDisplay(t *testing.T) {
+	ctx := context.Background()
+	repos := newMemoryStore(time.Now())
+	owner := uuid.New()
+	form := repos.createForm(t, &models.Form{UserID: owner, Title: "Plans", Status: models.FormStatusDraft})
+	svc := repos.formService()
+
+	randomized := &models.QuestionDisplay{RandomizeOptions: true, PinLastOption: true}
+	for _, questionType := range []models.QuestionType{models.QuestionTypeText, models.QuestionTypeNumber, models.QuestionTypeFile} {
+		if _, err := svc.AddQuestion(ctx, form.ID, owner, AddQuestionRequest{Type: questionType, Title: "Plan?", Display: randomized}); !errors.Is(err, ErrInvalidDisplay) {
+			t.Errorf("randomized %s question: err = %v, want ErrInvalidDisplay", questionType, err)
+		}
+	}
+	pinned := &models.QuestionDisplay{PinLastOption: true}
+	if _, err := svc.AddQuestion(ctx, form.ID, owner, AddQuestionRequest{Type: models.QuestionTypeRadio, Title: "Plan?", Display: pinned}); !errors.Is(err, ErrInvalidDisplay) {
+		t.Errorf("pinned option without randomizing: err = %v, want ErrInvalidDisplay", err)
+	}
+
+	question, err := svc.AddQuestion(ctx, form.ID, owner, AddQuestionRequest{Type: models.QuestionTypeRadio, Title: "Plan?", Display: randomized})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if display, err := question.GetDisplay(); err != nil || display != *randomized {
+		t.Errorf("display = %+v, %v; want %+v", display, err, *randomized)
+	}
+	text := models.QuestionTypeText
+	if _, err := svc.UpdateQuestion(ctx, question.ID, owner, UpdateQuestionRequest{Type: &text}); !errors.Is(err, ErrInvalidDisplay) {
+		t.Errorf("randomized question changed to text: err = %v, want ErrInvalidDisplay", err)
+	}
+	if _, err := svc.UpdateQuestion(ctx, question.ID, owner, UpdateQuestionRequest{Type: &text, Display: &models.QuestionDisplay{}}); err != nil {
+		t.Errorf("question changed to text without randomizing: %v", err)
+	}
+}
+
+func TestAuthorizeTestSubmission(t *testing.T) {
+	ctx := context.Background()
+	repos := newMemoryStore(time.Now())
+	svc := repos.formService()
+	owner := uuid.New()
+	form := repos.createForm(t, &models.Form{UserID: owner, Title: "Survey", Status: models.FormStatusDraft})
+
+	if err := svc.AuthorizeTestSubmission(ctx, form.ID, owner); !errors.Is(err, ErrFormNotFound) {
+		t.Errorf("draft form: err = %v, want ErrFormNotFound", err)
+	}
+	form.Status = models.FormStatusPublished
+	if err := repos.forms.Update(ctx, form); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.AuthorizeTestSubmission(ctx, form.ID, owner); err != nil {
+		t.Errorf("owner of the published form: err = %v, want none", err)
+	}
+	if err := svc.AuthorizeTestSubmission(ctx, form.ID, uuid.New()); !errors.Is(err, ErrNotFormOwner) {
+		t.Errorf("stranger: err = %v, want ErrNotFormOwner", err)
+	}
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/access"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/analytics"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/funnel"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+)
+
+// funnelStageTTL is how long the stages of a session are remembered: a view
+// token is accepted on the day it was issued and the next
+const funnelStageTTL = 48 * time.Hour
+
+// Outcomes of funnel beacons. Only counted beacons are published; the
+// others are dropped, the client being answered the same either way.
+const (
+	BeaconCounted   = "counted"
+	BeaconDuplicate = "duplicate"
+	BeaconTest      = "test"
+	BeaconInvalid   = "invalid"
+	BeaconThrottled = "throttled"
+)
+
+// FunnelService defines the interface for the funnels of public forms: the
+// beacons their clients send as respondents view and start them, and the
+// daily funnel reported to those who may view the form
+type FunnelService interface {
+	// Beacon records a beacon of client, the IP of the respondent, and
+	// returns its outcome
+	Beacon(ctx context.Context, formID uuid.UUID, client string, beacon FunnelBeacon) (string, error)
+	Funnel(ctx context.Context, formID, userID uuid.UUID, from, to string) (*analytics.FunnelReport, error)
+}
+
+// FunnelBeacon is a beacon of the client of a public form
+type FunnelBeacon struct {
+	// ViewToken is the view_token of the definition of the form
+	ViewToken string `json:"view_token" example:"20261016.6f1c2d9e8a7b4c3d2e1f0a9b8c7d6e5f.e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"`
+	// Stage is view once the form is shown, and start at the first
+	// interaction with an answer
+	Stage string `json:"stage" enums:"view,start" example:"view"`
+	// Test is set by clients testing the form, whose beacons are dropped
+	Test bool `json:"test,omitempty"`
+}
+
+// FunnelConfig bounds the beacons of the clients of public forms
+type FunnelConfig struct {
+	// RateLimit is the most beacons of a client to a form per RateWindow
+	RateLimit  int
+	RateWindow time.Duration
+}
+
+// funnelService implements FunnelService interface
+type funnelService struct {
+	tokens    *funnel.Tokens
+	beacons   repository.FunnelBeacons
+	publisher events.Publisher
+	funnels   analytics.FunnelReader
+	guard     formGuard
+	config    FunnelConfig
+	now       func() time.Time
+}
+
+// NewFunnelService creates a new funnel service instance verifying view
+// tokens with tokens and reading funnels from funnels, nil when the
+// projection isn't configured
+func NewFunnelService(formRepo repository.FormRepository, collaboratorRepo repository.CollaboratorRepository, orgRepo repository.OrganizationRepository, tokens *funnel.Tokens, beacons repository.FunnelBeacons, publisher events.Publisher, funnels analytics.FunnelReader, config FunnelConfig) FunnelService {
+	return &funnelService{
+		tokens:    tokens,
+		beacons:   beacons,
+		publisher: publisher,
+		funnels:   funnels,
+		guard:     formGuard{forms: formRepo, collaborators: collaboratorRepo, orgs: organizationScope{orgs: orgRepo}},
+		config:    config,
+		now:       time.Now,
+	}
+}
+
+// Beacon publishes the first beacon of each stage of a session. Beacons of
+// test clients, with a view token not issued for the form or beyond the
+// rate limit of the client are dropped; so are those repeating a stage of
+// the session. The token is checked before the beacon is counted against
+// the rate limit, so forged beacons cost no Redis writes. Beacons are best
+// effort: one whose event fails to be published is not counted.
+func (s *funnelService) Beacon(ctx context.Context, formID uuid.UUID, client string, beacon FunnelBeacon) (string, error) {
+	if beacon.Test {
+		return BeaconTest, nil
+	}
+	if !funnel.ValidStage(beacon.Stage) {
+		return BeaconInvalid, nil
+	}
+	session, err := s.tokens.Verify(formID, beacon.ViewToken)
+	if err != nil {
+		return BeaconInvalid, nil
+	}
+
+	count, err := s.beacons.Count(ctx, formID, client, s.config.RateWindow)
+	if err != nil {
+		return "", err
+	}
+	if count > int64(s.config.RateLimit) {
+		return BeaconThrottled, nil
+	}
+
+	first, err := s.beacons.Record(ctx, formID, session, beacon.Stage, funnelStageTTL)
+	if err != nil {
+		return "", err
+	}
+	if !first {
+		return BeaconDuplicate, nil
+	}
+
+	err = s.publisher.Publish(ctx, events.FunnelStage, formID.String(), map[string]interface{}{
+		"form_id":     formID.String(),
+		"session_id":  session,
+		"stage":       beacon.Stage,
+		"occurred_at": s.now().UTC(),
+	})
+	if err != nil {
+		return "", err
+	}
+	return BeaconCounted, nil
+}
+
+// Funnel reports the daily funnel of a form from from to to, days as
+// YYYY-MM-DD, to whoever may view the form
+func (s *funnelService) Funnel(ctx context.Context, formID, userID uuid.UUID, from, to string) (*analytics.FunnelReport, error) {
+	now := s.now()
+	start, end, err := analytics.ParseFunnelRange(from, to, now)
+	if err != nil {
+		return nil, err
+	}
+	form, err := s.guard.authorize(ctx, formID, userID, access.View)
+	if err != nil {
+		return nil, err
+	}
+	if s.funnels == nil {
+		return nil, analytics.ErrNotConfigured
+	}
+
+	days, err := s.funnels.FunnelDays(ctx, form.ID.String(), start, end)
+	if err != nil {
+		return nil, err
+	}
+	return analytics.BuildFunnel(form.ID.String(), days, start, end, now), nil
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/analytics"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/funnel"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+)
+
+// memoryFunnelBeacons keeps the stages of sessions and the beacon counts of
+// clients in memory, never expiring them
+type memoryFunnelBeacons struct {
+	mu     sync.Mutex
+	stages map[string]bool
+	counts map[string]int64
+}
+
+func newMemoryFunnelBeacons() *memoryFunnelBeacons {
+	return &memoryFunnelBeacons{stages: make(map[string]bool), counts: make(map[string]int64)}
+}
+
+func (b *memoryFunnelBeacons) Record(_ context.Context, formID uuid.UUID, session, stage string, _ time.Duration) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	key := fmt.Sprintf("%s:%s:%s", formID, session, stage)
+	if b.stages[key] {
+		return false, nil
+	}
+	b.stages[key] = true
+	return true, nil
+}
+
+func (b *memoryFunnelBeacons) Count(_ context.Context, formID uuid.UUID, client string, _ time.Duration) (int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	key := formID.String() + ":" + client
+	b.counts[key]++
+	return b.counts[key], nil
+}
+
+// fakeFunnels returns the same counted days for every form
+type fakeFunnels struct {
+	days []analytics.FunnelDay
+}
+
+func (f *fakeFunnels) FunnelDays(context.Context, string, time.Time, time.Time) ([]analytics.FunnelDay, error) {
+	return f.days, nil
+}
+
+func TestFunnelBeaconsCountSessionsOnce(t *testing.T) {
+	ctx := context.Background()
+	formID := uuid.New()
+	tokens := funnel.NewTokens("secret")
+	publisher := &recordingPublisher{}
+	svc := NewFunnelService(nil, nil, nil, tokens, newMemoryFunnelBeacons(), publisher, nil, FunnelConfig{RateLimit: 5, RateWindow: time.Minute})
+
+	token := tokens.Issue(formID, "respondent-1")
+	for i, tt := range []struct {
+		name   string
+		client string
+		beacon FunnelBeacon
+		want   string
+	}{
+		{"view", "10.0.0.1", FunnelBeacon{ViewToken: token, Stage: funnel.StageView}, BeaconCounted},
+		// Reloads of the form are the same session
+		{"view again", "10.0.0.1", FunnelBeacon{ViewToken: token, Stage: funnel.StageView}, BeaconDuplicate},
+		{"start", "10.0.0.1", FunnelBeacon{ViewToken: token, Stage: funnel.StageStart}, BeaconCounted},
+		{"start again", "10.0.0.1", FunnelBeacon{ViewToken: token, Stage: funnel.StageStart}, BeaconDuplicate},
+		{"other respondent", "10.0.0.2", FunnelBeacon{ViewToken: tokens.Issue(formID, "respondent-2"), Stage: funnel.StageView}, BeaconCounted},
+		{"test", "10.0.0.3", FunnelBeacon{ViewToken: tokens.Issue(formID, "respondent-3"), Stage: funnel.StageView, Test: true}, BeaconTest},
+		{"forged", "10.0.0.3", FunnelBeacon{ViewToken: funnel.NewTokens("other").Issue(formID, "respondent-3"), Stage: funnel.StageView}, BeaconInvalid},
+		{"other form", "10.0.0.3", FunnelBeacon{ViewToken: tokens.Issue(uuid.New(), "respondent-3"), Stage: funnel.StageView}, BeaconInvalid},
+		{"submit", "10.0.0.3", FunnelBeacon{ViewToken: tokens.Issue(formID, "respondent-3"), Stage: "submit"}, BeaconInvalid},
+	} {
+		got, err := svc.Beacon(ctx, formID, tt.client, tt.beacon)
+		if err != nil {
+			t.Fatalf("%d %s: %v", i, tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: outcome = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	if len(publisher.events) != 3 {
+		t.Fatalf("published %v, want the view and start of the first respondent and the view of the second", publisher.events)
+	}
+	for i, stage := range []string{funnel.StageView, funnel.StageStart, funnel.StageView} {
+		data := publisher.data[i]
+		if publisher.events[i] != events.FunnelStage || publisher.keys[i] != formID.String() || data["stage"] != stage || data["form_id"] != formID.String() {
+			t.Errorf("event %d = %s %v, want the %s of the form", i, publisher.events[i], data, stage)
+		}
+	}
+	if publisher.data[0]["session_id"] != publisher.data[1]["session_id"] || publisher.data[0]["session_id"] == publisher.data[2]["session_id"] {
+		t.Errorf("sessions = %v, %v and %v, want the first two alike", publisher.data[0]["session_id"], publisher.data[1]["session_id"], publisher.data[2]["session_id"])
+	}
+}
+
+func TestFunnelBeaconsAreRateLimited(t *testing.T) {
+	ctx := context.Background()
+	formID := uuid.New()
+	tokens := funnel.NewTokens("secret")
+	publisher := &recordingPublisher{}
+	svc := NewFunnelService(nil, nil, nil, tokens, newMemoryFunnelBeacons(), publisher, nil, FunnelConfig{RateLimit: 3, RateWindow: time.Minute})
+
+	var outcomes []string
+	for i := 0; i < 5; i++ {
+		beacon := FunnelBeacon{ViewToken: tokens.Issue(formID, fmt.Sprintf("respondent-%d", i)), Stage: funnel.StageView}
+		outcome, err := svc.Beacon(ctx, formID, "10.0.0.1", beacon)
+		if err != nil {
+			t.Fatal(err)
+		}
+		outcomes = append(outcomes, outcome)
+	}
+	want := []string{BeaconCounted, BeaconCounted, BeaconCounted, BeaconThrottled, BeaconThrottled}
+	if fmt.Sprint(outcomes) != fmt.Sprint(want) || len(publisher.events) != 3 {
+		t.Errorf("outcomes = %v with %d events, want %v", outcomes, len(publisher.events), want)
+	}
+
+	// Other clients have their own limit
+	beacon := FunnelBeacon{ViewToken: tokens.Issue(formID, "respondent-9"), Stage: funnel.StageView}
+	if outcome, err := svc.Beacon(ctx, formID, "10.0.0.2", beacon); err != nil || outcome != BeaconCounted {
+		t.Errorf("beacon of another client = %s, %v, want counted", outcome, err)
+	}
+}
+
+func TestFunnelReport(t *testing.T) {
+	ctx := context.Background()
+	owner := uuid.New()
+	repos := newMemoryStore(time.Now())
+	form := repos.createForm(t, &models.Form{UserID: owner, Title: "Signup", Status: models.FormStatusPublished})
+	today := time.Now().UTC().Format("2006-01-02")
+	funnels := &fakeFunnels{days: []analytics.FunnelDay{
+		{Date: today, FunnelCounts: analytics.FunnelCounts{Views: 10, Starts: 4, Submissions: 2}},
+	}}
+	svc := NewFunnelService(repos.forms, nil, nil, funnel.NewTokens("secret"), newMemoryFunnelBeacons(), &recordingPublisher{}, funnels, FunnelConfig{})
+
+	report, err := svc.Funnel(ctx, form.ID, owner, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Days) != analytics.DefaultFunnelDays || report.To != today {
+		t.Errorf("report of %d days to %s, want %d to today", len(report.Days), report.To, analytics.DefaultFunnelDays)
+	}
+	if report.Totals.Views != 10 || report.Totals.Submissions != 2 {
+		t.Errorf("totals = %+v", report.Totals)
+	}
+
+	if _, err := svc.Funnel(ctx, form.ID, owner, today, "2020-01-01"); !errors.Is(err, analytics.ErrInvalidRange) {
+		t.Errorf("range backwards: err = %v, want ErrInvalidRange", err)
+	}
+	if _, err := svc.Funnel(ctx, form.ID, uuid.New(), "", ""); !errors.Is(err, ErrNotFormOwner) {
+		t.Errorf("not the owner: err = %v, want ErrNotFormOwner", err)
+	}
+	unconfigured := NewFunnelService(repos.forms, nil, nil, funnel.NewTokens("secret"), newMemoryFunnelBeacons(), &recordingPublisher{}, nil, FunnelConfig{})
+	if _, err := unconfigured.Funnel(ctx, form.ID, owner, "", ""); !errors.Is(err, analytics.ErrNotConfigured) {
+		t.Errorf("without a projection database: err = %v, want ErrNotConfigured", err)
+	}
+}
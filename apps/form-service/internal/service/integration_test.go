@@ -0,0 +1,223 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/service"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/testutil"
+)
+
+// The integration tests run against PostgreSQL, Redis and a recording event
+// bus; see the testutil package. Skip them with -short.
+func TestMain(m *testing.M) {
+	os.Exit(testutil.Run(m))
+}
+
+// addQuestions adds the questions titled, in order
+func addQuestions(t *testing.T, app *testutil.App, formID, owner uuid.UUID, titles ...string) []*models.Question {
+	t.Helper()
+	questions := make([]*models.Question, len(titles))
+	for i, title := range titles {
+		question, err := app.Forms.AddQuestion(context.Background(), formID, owner, service.AddQuestionRequest{
+			Type: models.QuestionTypeText, Title: title, Order: i,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		questions[i] = question
+	}
+	return questions
+}
+
+// questionTitles returns the titles of the questions of a form as stored,
+// in order
+func questionTitles(t *testing.T, app *testutil.App, formID uuid.UUID) []string {
+	t.Helper()
+	questions, err := repository.NewQuestionRepository(app.DB).GetByFormID(context.Background(), formID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	titles := make([]string, len(questions))
+	for i, question := range questions {
+		titles[i] = question.Title
+	}
+	return titles
+}
+
+func TestIntegrationFormCRUD(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewApp(t)
+	ctx := context.Background()
+	owner := uuid.New()
+
+	form, err := app.Forms.CreateForm(ctx, owner, service.CreateFormRequest{Title: "Onboarding", Slug: "onboarding"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	questions := addQuestions(t, app, form.ID, owner, "Name", "Team", "Start date")
+
+	// The batch is reordered in one transaction
+	err = app.Forms.ReorderQuestions(ctx, form.ID, owner, service.ReorderQuestionsRequest{QuestionOrders: []service.QuestionOrder{
+		{ID: questions[0].ID, Order: 2}, {ID: questions[1].ID, Order: 0}, {ID: questions[2].ID, Order: 1},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	title := "Your team"
+	if _, err := app.Forms.UpdateQuestion(ctx, questions[1].ID, owner, service.UpdateQuestionRequest{Title: &title}); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.Forms.DeleteQuestion(ctx, questions[2].ID, owner); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := questionTitles(t, app, form.ID), []string{"Your team", "Name"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("questions = %v, want %v", got, want)
+	}
+
+	// Reordering a question of another form changes nothing
+	other, err := app.Forms.CreateForm(ctx, owner, service.CreateFormRequest{Title: "Offboarding"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = app.Forms.ReorderQuestions(ctx, other.ID, owner, service.ReorderQuestionsRequest{QuestionOrders: []service.QuestionOrder{
+		{ID: questions[0].ID, Order: 0},
+	}})
+	if err == nil {
+		t.Error("reordering the question of another form succeeded")
+	}
+
+	page, err := app.Activity.ListActivity(ctx, form.ID, owner, service.ActivityQuery{Limit: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actions []models.ActivityAction
+	for _, activity := range page.Activities {
+		actions = append(actions, activity.Action)
+	}
+	want := []models.ActivityAction{
+		models.ActivityQuestionDeleted, models.ActivityQuestionUpdated, models.ActivityQuestionsReordered,
+		models.ActivityQuestionAdded, models.ActivityQuestionAdded, models.ActivityQuestionAdded, models.ActivityFormCreated,
+	}
+	if !reflect.DeepEqual(actions, want) {
+		t.Errorf("activity feed = %v, want %v", actions, want)
+	}
+
+	if err := app.Forms.DeleteForm(ctx, form.ID, owner); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.Forms.GetForm(ctx, form.ID, owner); err == nil {
+		t.Error("deleted form still found")
+	}
+	if got := app.Events.Events(events.FormDeleted); len(got) != 1 || got[0].Key != form.ID.String() {
+		t.Errorf("form.deleted events = %+v, want one keyed by the form", got)
+	}
+	app.Events.WaitFor(t, "audit.form.deleted", 1, 5*time.Second)
+}
+
+func TestIntegrationPublish(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewApp(t)
+	ctx := context.Background()
+	owner := uuid.New()
+
+	form, err := app.Forms.CreateForm(ctx, owner, service.CreateFormRequest{Title: "Feedback", Slug: "feedback"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addQuestions(t, app, form.ID, owner, "How was it?")
+
+	// Drafts are not resolved by their slug
+	if _, err := app.Forms.ResolveSlug(ctx, uuid.Nil, "feedback"); !errors.Is(err, service.ErrFormNotFound) {
+		t.Errorf("resolving a draft: err = %v, want ErrFormNotFound", err)
+	}
+
+	if _, _, err := app.Forms.PublishForm(ctx, form.ID, owner); err != nil {
+		t.Fatal(err)
+	}
+	resolved, err := app.Forms.ResolveSlug(ctx, uuid.Nil, "feedback")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved.Form.ID != form.ID || len(resolved.Questions) != 1 {
+		t.Errorf("resolved %+v, want the form with its question", resolved.PublishedForm)
+	}
+
+	published := app.Events.Events(events.FormPublished)
+	if len(published) != 1 || published[0].Source != "form-service" || published[0].Data["slug"] != "feedback" {
+		t.Errorf("form.published events = %+v, want one carrying the slug", published)
+	}
+	app.Events.WaitFor(t, "audit.form.published", 1, 5*time.Second)
+
+	// Respondents save drafts of published forms in Redis
+	owned := models.DraftOwner{RespondentToken: "respondent-1"}
+	answers := json.RawMessage(`{"q1":"Great"}`)
+	if _, err := app.Drafts.SaveDraft(ctx, form.ID, owned, service.SaveDraftRequest{Answers: answers}); err != nil {
+		t.Fatal(err)
+	}
+	draft, err := app.Drafts.GetDraft(ctx, form.ID, owned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(draft.Answers) != string(answers) {
+		t.Errorf("draft answers = %s, want %s", draft.Answers, answers)
+	}
+}
+
+func TestIntegrationConcurrentSlugClaims(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewApp(t)
+	ctx := context.Background()
+	owner := uuid.New()
+
+	forms := make([]*models.Form, 4)
+	for i := range forms {
+		form, err := app.Forms.CreateForm(ctx, owner, service.CreateFormRequest{Title: "Survey"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		forms[i] = form
+	}
+
+	// The forms claim the same slug at once; the unique index settles it
+	slug := "survey"
+	errs := make([]error, len(forms))
+	var wg sync.WaitGroup
+	for i, form := range forms {
+		wg.Add(1)
+		go func(i int, form *models.Form) {
+			defer wg.Done()
+			_, errs[i] = app.Forms.UpdateForm(ctx, form.ID, owner, service.UpdateFormRequest{Slug: &slug})
+		}(i, form)
+	}
+	wg.Wait()
+
+	winners := 0
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			winners++
+		case !errors.Is(err, service.ErrSlugTaken):
+			t.Errorf("claim %d: err = %v, want ErrSlugTaken", i, err)
+		}
+	}
+	if winners != 1 {
+		t.Errorf("%d forms got the slug, want 1", winners)
+	}
+	var claimed int64
+	app.DB.Model(&models.Form{}).Where("slug = ?", slug).Count(&claimed)
+	if claimed != 1 {
+		t.Errorf("%d forms stored with the slug, want 1", claimed)
+	}
+}
@@ -0,0 +1,384 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/access"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+)
+
+// ErrInvalidLibraryQuestion is returned for library question definitions
+// that would not make valid form questions
+var ErrInvalidLibraryQuestion = errors.New("invalid library question")
+
+// MaxLibraryInsert bounds the library questions inserted into a form at once
+const MaxLibraryInsert = 50
+
+// LibraryService defines the interface for the question bank of an
+// organization. Requests act on the library of the organization they act
+// in, or else of the personal organization of the user. Members manage the
+// library and copy its questions into the forms they may edit; owners and
+// admins propagate updated questions into the draft forms of every member.
+type LibraryService interface {
+	CreateQuestion(ctx context.Context, userID uuid.UUID, req LibraryQuestionRequest) (*models.LibraryQuestion, error)
+	GetQuestion(ctx context.Context, userID, id uuid.UUID) (*models.LibraryQuestion, error)
+	UpdateQuestion(ctx context.Context, userID, id uuid.UUID, req UpdateLibraryQuestionRequest) (*models.LibraryQuestion, error)
+	DeleteQuestion(ctx context.Context, userID, id uuid.UUID) error
+	SearchQuestions(ctx context.Context, userID uuid.UUID, search repository.LibrarySearch, page, limit int) (*LibraryQuestionList, error)
+
+	InsertIntoForm(ctx context.Context, formID, userID uuid.UUID, req InsertFromLibraryRequest) ([]*models.Question, error)
+	ListUsages(ctx context.Context, userID, id uuid.UUID) (*LibraryUsages, error)
+	Propagate(ctx context.Context, userID, id uuid.UUID) (*PropagateResult, error)
+}
+
+// LibraryQuestionRequest represents a request to add a question to the
+// library
+type LibraryQuestionRequest struct {
+	Type        models.QuestionType `json:"type" binding:"required" example:"radio"`
+	Title       string              `json:"title" binding:"required,max=500" example:"What is your age group?"`
+	Description string              `json:"description" binding:"max=1000"`
+	Options     interface{}         `json:"options,omitempty"`
+	Validation  interface{}         `json:"validation,omitempty"`
+	Tags        []string            `json:"tags,omitempty" example:"demographics"`
+}
+
+// UpdateLibraryQuestionRequest represents a request to update a library
+// question. Changes of the definition bump its version; tags don't.
+type UpdateLibraryQuestionRequest struct {
+	Type        *models.QuestionType `json:"type,omitempty"`
+	Title       *string              `json:"title,omitempty" binding:"omitempty,max=500"`
+	Description *string              `json:"description,omitempty" binding:"omitempty,max=1000"`
+	Options     interface{}          `json:"options,omitempty"`
+	Validation  interface{}          `json:"validation,omitempty"`
+	Tags        *[]string            `json:"tags,omitempty"`
+}
+
+// InsertFromLibraryRequest copies library questions into a form, in the
+// order listed, starting at Position. Questions from Position on move down;
+// without Position the copies are appended.
+type InsertFromLibraryRequest struct {
+	QuestionIDs []uuid.UUID `json:"question_ids" binding:"required,min=1,max=50"`
+	Position    *int        `json:"position,omitempty" binding:"omitempty,min=0" example:"1"`
+}
+
+// LibraryQuestionList is a page of library questions
+type LibraryQuestionList struct {
+	Questions  []*models.LibraryQuestion `json:"questions"`
+	Total      int64                     `json:"total"`
+	Page       int                       `json:"page"`
+	Limit      int                       `json:"limit"`
+	TotalPages int                       `json:"total_pages"`
+}
+
+// LibraryUsages lists the form questions copied from older versions of a
+// library question
+type LibraryUsages struct {
+	LibraryQuestionID uuid.UUID                     `json:"library_question_id"`
+	Version           int                           `json:"version"`
+	Usages            []models.LibraryQuestionUsage `json:"usages"`
+}
+
+// PropagateResult reports the draft forms updated to the current version of
+// a library question, and the copies of older versions left in published
+// and closed forms
+type PropagateResult struct {
+	LibraryQuestionID uuid.UUID   `json:"library_question_id"`
+	Version           int         `json:"version"`
+	UpdatedForms      []uuid.UUID `json:"updated_forms"`
+	Skipped           int         `json:"skipped"`
+}
+
+// libraryService implements LibraryService interface
+type libraryService struct {
+	library      repository.LibraryRepository
+	questionRepo repository.QuestionRepository
+	orgRepo      repository.OrganizationRepository
+	guard        formGuard
+	auditor      events.Auditor
+	definitions  *DefinitionCache
+}
+
+// NewLibraryService creates a new library service instance. Questions are
+// copied into the forms the user may edit as collaborators allow, and
+// propagations are recorded to auditor. The forms questions are copied into
+// are dropped from definitions, which may be nil.
+func NewLibraryService(library repository.LibraryRepository, formRepo repository.FormRepository, questionRepo repository.QuestionRepository, collaboratorRepo repository.CollaboratorRepository, orgRepo repository.OrganizationRepository, auditor events.Auditor, definitions *DefinitionCache) LibraryService {
+	return &libraryService{
+		library:      library,
+		questionRepo: questionRepo,
+		orgRepo:      orgRepo,
+		guard:        formGuard{forms: formRepo, collaborators: collaboratorRepo, orgs: organizationScope{orgs: orgRepo}},
+		auditor:      auditor,
+		definitions:  definitions,
+	}
+}
+
+// CreateQuestion adds a question to the library of the organization
+func (s *libraryService) CreateQuestion(ctx context.Context, userID uuid.UUID, req LibraryQuestionRequest) (*models.LibraryQuestion, error) {
+	orgID, err := s.guard.orgs.home(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	question := &models.LibraryQuestion{
+		OrganizationID: orgID,
+		Type:           req.Type,
+		Title:          req.Title,
+		Description:    req.Description,
+		Tags:           req.Tags,
+		CreatedBy:      userID,
+	}
+	if question.Options, err = encodeDefinition(req.Options); err != nil {
+		return nil, err
+	}
+	if question.Validation, err = encodeDefinition(req.Validation); err != nil {
+		return nil, err
+	}
+	if err := question.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidLibraryQuestion, err)
+	}
+
+	if err := s.library.Create(ctx, question); err != nil {
+		return nil, fmt.Errorf("failed to create library question: %w", err)
+	}
+	return question, nil
+}
+
+// GetQuestion gets a question of the library of the organization
+func (s *libraryService) GetQuestion(ctx context.Context, userID, id uuid.UUID) (*models.LibraryQuestion, error) {
+	orgID, err := s.guard.orgs.home(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.library.Get(ctx, orgID, id)
+}
+
+// UpdateQuestion updates a library question. Forms keep the copies they
+// have until the update is propagated.
+func (s *libraryService) UpdateQuestion(ctx context.Context, userID, id uuid.UUID, req UpdateLibraryQuestionRequest) (*models.LibraryQuestion, error) {
+	question, err := s.GetQuestion(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := req.Type != nil || req.Title != nil || req.Description != nil || req.Options != nil || req.Validation != nil
+	if req.Type != nil {
+		question.Type = *req.Type
+	}
+	if req.Title != nil {
+		question.Title = *req.Title
+	}
+	if req.Description != nil {
+		question.Description = *req.Description
+	}
+	if req.Options != nil {
+		if question.Options, err = encodeDefinition(req.Options); err != nil {
+			return nil, err
+		}
+	}
+	if req.Validation != nil {
+		if question.Validation, err = encodeDefinition(req.Validation); err != nil {
+			return nil, err
+		}
+	}
+	if req.Tags != nil {
+		question.Tags = *req.Tags
+	}
+	if changed {
+		question.Version++
+	}
+	if err := question.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidLibraryQuestion, err)
+	}
+
+	if err := s.library.Update(ctx, question); err != nil {
+		return nil, fmt.Errorf("failed to update library question: %w", err)
+	}
+	return question, nil
+}
+
+// DeleteQuestion removes a question from the library. Its copies stay in
+// their forms.
+func (s *libraryService) DeleteQuestion(ctx context.Context, userID, id uuid.UUID) error {
+	orgID, err := s.guard.orgs.home(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.library.Delete(ctx, orgID, id)
+}
+
+// SearchQuestions lists the library questions matching search, most
+// recently updated first
+func (s *libraryService) SearchQuestions(ctx context.Context, userID uuid.UUID, search repository.LibrarySearch, page, limit int) (*LibraryQuestionList, error) {
+	page, limit = normalizePage(page, limit)
+
+	orgID, err := s.guard.orgs.home(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make([]string, 0, len(search.Tags))
+	for _, tag := range search.Tags {
+		if tag = models.NormalizeTag(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	search.Tags = tags
+
+	questions, total, err := s.library.Search(ctx, orgID, search, (page-1)*limit, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search library questions: %w", err)
+	}
+
+	totalPages := int(total) / limit
+	if int(total)%limit > 0 {
+		totalPages++
+	}
+	return &LibraryQuestionList{
+		Questions:  questions,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// InsertIntoForm copies library questions of the organization of the form
+// into it. Each copy records the library question and version it was copied
+// from.
+func (s *libraryService) InsertIntoForm(ctx context.Context, formID, userID uuid.UUID, req InsertFromLibraryRequest) ([]*models.Question, error) {
+	if len(req.QuestionIDs) == 0 || len(req.QuestionIDs) > MaxLibraryInsert {
+		return nil, fmt.Errorf("%w: insert between 1 and %d questions", ErrInvalidLibraryQuestion, MaxLibraryInsert)
+	}
+
+	form, err := s.guard.authorize(ctx, formID, userID, access.Edit)
+	if err != nil {
+		return nil, err
+	}
+
+	copies := make([]*models.Question, 0, len(req.QuestionIDs))
+	for _, id := range req.QuestionIDs {
+		question, err := s.library.Get(ctx, form.OrganizationID, id)
+		if err != nil {
+			return nil, err
+		}
+		copies = append(copies, question.Copy(form.ID, 0))
+	}
+
+	var position int
+	if req.Position != nil {
+		position = *req.Position
+	} else {
+		maxOrder, err := s.questionRepo.GetMaxOrder(ctx, form.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get max order: %w", err)
+		}
+		position = maxOrder + 1
+	}
+
+	// The copies get keys from their titles, unique within the form
+	taken, err := takenQuestionKeys(ctx, s.questionRepo, form.ID, uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := assignQuestionKeys(copies, taken); err != nil {
+		return nil, err
+	}
+
+	err = s.library.CopyIntoForm(ctx, form.ID, position, copies)
+	if errors.Is(err, repository.ErrQuestionKeyTaken) {
+		return nil, ErrQuestionKeyTaken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert library questions: %w", err)
+	}
+	s.definitions.invalidate(ctx, form.ID)
+	return copies, nil
+}
+
+// ListUsages lists the form questions copied from older versions of the
+// library question
+func (s *libraryService) ListUsages(ctx context.Context, userID, id uuid.UUID) (*LibraryUsages, error) {
+	question, err := s.GetQuestion(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	usages, err := s.library.Usages(ctx, question)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list library question usages: %w", err)
+	}
+	return &LibraryUsages{LibraryQuestionID: question.ID, Version: question.Version, Usages: usages}, nil
+}
+
+// Propagate updates the copies of older versions of the library question in
+// draft forms to its current definition. Published and closed forms keep
+// the definition respondents see.
+func (s *libraryService) Propagate(ctx context.Context, userID, id uuid.UUID) (*PropagateResult, error) {
+	question, err := s.GetQuestion(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	member, err := s.orgRepo.GetMember(ctx, question.OrganizationID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get member: %w", err)
+	}
+	if !member.Role.CanManageLibrary() {
+		return nil, ErrInsufficientOrganizationRole
+	}
+
+	updated, err := s.library.Propagate(ctx, question)
+	if err != nil {
+		return nil, fmt.Errorf("failed to propagate library question: %w", err)
+	}
+	remaining, err := s.library.Usages(ctx, question)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list library question usages: %w", err)
+	}
+	if updated == nil {
+		updated = []uuid.UUID{}
+	}
+
+	s.auditor.Record(ctx, events.AuditEvent{
+		Action:       events.AuditLibraryQuestionPropagated,
+		Actor:        userID.String(),
+		ResourceType: "library_question",
+		ResourceID:   question.ID.String(),
+		After: map[string]interface{}{
+			"version":       question.Version,
+			"updated_forms": len(updated),
+		},
+	})
+
+	return &PropagateResult{
+		LibraryQuestionID: question.ID,
+		Version:           question.Version,
+		UpdatedForms:      updated,
+		Skipped:           len(remaining),
+	}, nil
+}
+
+// encodeDefinition encodes the options or validation of a question
+func encodeDefinition(v interface{}) (datatypes.JSON, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidLibraryQuestion, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,308 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/tenant"
+)
+
+// memoryLibraryRepository keeps library questions and the questions of forms
+// in memory, reading form statuses from forms
+type memoryLibraryRepository struct {
+	repository.LibraryRepository
+
+	mu        sync.Mutex
+	forms     *memoryFormRepository
+	library   map[uuid.UUID]models.LibraryQuestion
+	questions map[uuid.UUID]*models.Question
+}
+
+func newMemoryLibraryRepository(forms *memoryFormRepository) *memoryLibraryRepository {
+	return &memoryLibraryRepository{
+		forms:     forms,
+		library:   make(map[uuid.UUID]models.LibraryQuestion),
+		questions: make(map[uuid.UUID]*models.Question),
+	}
+}
+
+func (r *memoryLibraryRepository) Create(_ context.Context, question *models.LibraryQuestion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	question.ID, question.Version = uuid.New(), 1
+	r.library[question.ID] = *question
+	return nil
+}
+
+func (r *memoryLibraryRepository) Get(_ context.Context, orgID, id uuid.UUID) (*models.LibraryQuestion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	question, ok := r.library[id]
+	if !ok || question.OrganizationID != orgID {
+		return nil, repository.ErrLibraryQuestionNotFound
+	}
+	return &question, nil
+}
+
+func (r *memoryLibraryRepository) Update(_ context.Context, question *models.LibraryQuestion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.library[question.ID] = *question
+	return nil
+}
+
+// libraryQuestions is the question repository of the forms questions are
+// copied into
+type libraryQuestions struct {
+	repository.QuestionRepository
+	library *memoryLibraryRepository
+}
+
+func (r libraryQuestions) GetByFormID(_ context.Context, formID uuid.UUID) ([]*models.Question, error) {
+	questions := r.library.formQuestions(formID)
+	found := make([]*models.Question, len(questions))
+	for i := range questions {
+		found[i] = &questions[i]
+	}
+	return found, nil
+}
+
+func (r libraryQuestions) GetKeyAliases(context.Context, uuid.UUID) ([]*models.QuestionKeyAlias, error) {
+	return nil, nil
+}
+
+func (r libraryQuestions) GetMaxOrder(_ context.Context, formID uuid.UUID) (int, error) {
+	r.library.mu.Lock()
+	defer r.library.mu.Unlock()
+	maxOrder := 0
+	for _, question := range r.library.questions {
+		if question.FormID == formID && question.Order > maxOrder {
+			maxOrder = question.Order
+		}
+	}
+	return maxOrder, nil
+}
+
+func (r *memoryLibraryRepository) CopyIntoForm(_ context.Context, formID uuid.UUID, position int, copies []*models.Question) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, question := range r.questions {
+		if question.FormID == formID && question.Order >= position {
+			question.Order += len(copies)
+		}
+	}
+	for i, question := range copies {
+		question.ID, question.FormID, question.Order = uuid.New(), formID, position+i
+		r.questions[question.ID] = question
+	}
+	return nil
+}
+
+// formQuestions returns the questions of the form in order
+func (r *memoryLibraryRepository) formQuestions(formID uuid.UUID) []models.Question {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var questions []models.Question
+	for _, question := range r.questions {
+		if question.FormID == formID {
+			questions = append(questions, *question)
+		}
+	}
+	sort.Slice(questions, func(i, j int) bool { return questions[i].Order < questions[j].Order })
+	return questions
+}
+
+// outdated returns the copies of older versions of the library question
+func (r *memoryLibraryRepository) outdated(question *models.LibraryQuestion) []*models.Question {
+	var copies []*models.Question
+	for _, copied := range r.questions {
+		if copied.LibraryQuestionID != nil && *copied.LibraryQuestionID == question.ID && *copied.LibraryVersion < question.Version {
+			copies = append(copies, copied)
+		}
+	}
+	return copies
+}
+
+func (r *memoryLibraryRepository) Usages(ctx context.Context, question *models.LibraryQuestion) ([]models.LibraryQuestionUsage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	usages := []models.LibraryQuestionUsage{}
+	for _, copied := range r.outdated(question) {
+		form, _ := r.forms.GetByID(ctx, copied.FormID)
+		usages = append(usages, models.LibraryQuestionUsage{
+			FormID: form.ID, FormTitle: form.Title, FormStatus: form.Status,
+			QuestionID: copied.ID, LibraryVersion: *copied.LibraryVersion,
+		})
+	}
+	return usages, nil
+}
+
+func (r *memoryLibraryRepository) Propagate(ctx context.Context, question *models.LibraryQuestion) ([]uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var formIDs []uuid.UUID
+	for _, copied := range r.outdated(question) {
+		form, _ := r.forms.GetByID(ctx, copied.FormID)
+		if form.Status != models.FormStatusDraft {
+			continue
+		}
+		updated := question.Copy(copied.FormID, copied.Order)
+		updated.ID = copied.ID
+		r.questions[copied.ID] = updated
+		formIDs = append(formIDs, copied.FormID)
+	}
+	return formIDs, nil
+}
+
+// libraryFixture is an organization with an admin and a member, each owning
+// a draft form
+type libraryFixture struct {
+	*memoryStore
+	svc           LibraryService
+	library       *memoryLibraryRepository
+	admin, member uuid.UUID
+	inOrg         context.Context
+}
+
+func newLibraryFixture(t *testing.T) *libraryFixture {
+	t.Helper()
+	f := &libraryFixture{memoryStore: newMemoryStore(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)), admin: uuid.New(), member: uuid.New()}
+	f.library = newMemoryLibraryRepository(f.forms)
+	org := f.createOrganization(t, f.admin, map[uuid.UUID]models.OrganizationRole{f.member: models.OrganizationRoleMember})
+	f.inOrg = tenant.WithOrganization(context.Background(), org.ID)
+	f.svc = NewLibraryService(f.library, f.forms, libraryQuestions{library: f.library}, nil, f.orgs, &recordingAuditor{}, nil)
+	return f
+}
+
+// orgForm creates a form of the owner in the organization of the fixture
+func (f *libraryFixture) orgForm(t *testing.T, owner uuid.UUID, status models.FormStatus) *models.Form {
+	t.Helper()
+	orgID, _ := tenant.Organization(f.inOrg)
+	return f.createForm(t, &models.Form{UserID: owner, OrganizationID: orgID, Title: "Survey", Status: status})
+}
+
+func TestLibraryQuestionValidatedOnSave(t *testing.T) {
+	f := newLibraryFixture(t)
+
+	_, err := f.svc.CreateQuestion(f.inOrg, f.member, LibraryQuestionRequest{Type: models.QuestionTypeText, Title: "   "})
+	if !errors.Is(err, ErrInvalidLibraryQuestion) {
+		t.Errorf("blank title: err = %v, want ErrInvalidLibraryQuestion", err)
+	}
+
+	question, err := f.svc.CreateQuestion(f.inOrg, f.member, LibraryQuestionRequest{
+		Type: models.QuestionTypeRadio, Title: "Age group", Tags: []string{" Demographics", "demographics", "AGE"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(question.Tags) != 2 || question.Tags[0] != "demographics" || question.Tags[1] != "age" {
+		t.Errorf("tags = %v, want them normalized and deduplicated", question.Tags)
+	}
+
+	// Updates are validated too, and only definition changes bump the version
+	blank := ""
+	if _, err := f.svc.UpdateQuestion(f.inOrg, f.member, question.ID, UpdateLibraryQuestionRequest{Title: &blank}); !errors.Is(err, ErrInvalidLibraryQuestion) {
+		t.Errorf("update to a blank title: err = %v, want ErrInvalidLibraryQuestion", err)
+	}
+	tags := []string{"age"}
+	question, err = f.svc.UpdateQuestion(f.inOrg, f.member, question.ID, UpdateLibraryQuestionRequest{Tags: &tags})
+	if err != nil || question.Version != 1 {
+		t.Errorf("tags update = %+v, %v; want version 1", question, err)
+	}
+}
+
+func TestInsertFromLibrary(t *testing.T) {
+	f := newLibraryFixture(t)
+	form := f.orgForm(t, f.member, models.FormStatusDraft)
+	existing := []*models.Question{{Title: "Name"}, {Title: "Email"}}
+	if err := f.library.CopyIntoForm(context.Background(), form.ID, 1, existing); err != nil {
+		t.Fatal(err)
+	}
+
+	age, _ := f.svc.CreateQuestion(f.inOrg, f.member, LibraryQuestionRequest{Type: models.QuestionTypeRadio, Title: "Age group"})
+	country, _ := f.svc.CreateQuestion(f.inOrg, f.member, LibraryQuestionRequest{Type: models.QuestionTypeSelect, Title: "Country"})
+
+	position := 2
+	copies, err := f.svc.InsertIntoForm(f.inOrg, form.ID, f.member, InsertFromLibraryRequest{
+		QuestionIDs: []uuid.UUID{age.ID, country.ID}, Position: &position,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(copies) != 2 || *copies[0].LibraryQuestionID != age.ID || *copies[0].LibraryVersion != 1 {
+		t.Errorf("copies = %+v, want the provenance of each", copies)
+	}
+
+	var titles []string
+	for _, question := range f.library.formQuestions(form.ID) {
+		titles = append(titles, question.Title)
+	}
+	if want := []string{"Name", "Age group", "Country", "Email"}; !reflect.DeepEqual(titles, want) {
+		t.Errorf("questions = %v, want %v", titles, want)
+	}
+
+	// Without a position the copies are appended
+	if _, err := f.svc.InsertIntoForm(f.inOrg, form.ID, f.member, InsertFromLibraryRequest{QuestionIDs: []uuid.UUID{age.ID}}); err != nil {
+		t.Fatal(err)
+	}
+	questions := f.library.formQuestions(form.ID)
+	if last := questions[len(questions)-1]; last.Title != "Age group" || last.Order != 5 {
+		t.Errorf("appended question = %+v, want Age group at 5", last)
+	}
+
+	// Questions of other libraries are not found
+	if _, err := f.svc.InsertIntoForm(f.inOrg, form.ID, f.member, InsertFromLibraryRequest{QuestionIDs: []uuid.UUID{uuid.New()}}); !errors.Is(err, repository.ErrLibraryQuestionNotFound) {
+		t.Errorf("unknown library question: err = %v, want ErrLibraryQuestionNotFound", err)
+	}
+}
+
+func TestPropagateUpdatesDraftsOnly(t *testing.T) {
+	f := newLibraryFixture(t)
+	draft := f.orgForm(t, f.member, models.FormStatusDraft)
+	published := f.orgForm(t, f.admin, models.FormStatusPublished)
+
+	age, _ := f.svc.CreateQuestion(f.inOrg, f.admin, LibraryQuestionRequest{Type: models.QuestionTypeRadio, Title: "Age group"})
+	for _, form := range []*models.Form{draft, published} {
+		if _, err := f.svc.InsertIntoForm(f.inOrg, form.ID, form.UserID, InsertFromLibraryRequest{QuestionIDs: []uuid.UUID{age.ID}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	title := "What is your age group?"
+	age, err := f.svc.UpdateQuestion(f.inOrg, f.admin, age.ID, UpdateLibraryQuestionRequest{Title: &title})
+	if err != nil || age.Version != 2 {
+		t.Fatalf("update = %+v, %v; want version 2", age, err)
+	}
+
+	usages, err := f.svc.ListUsages(f.inOrg, f.member, age.ID)
+	if err != nil || len(usages.Usages) != 2 {
+		t.Fatalf("usages = %+v, %v; want both forms", usages, err)
+	}
+
+	if _, err := f.svc.Propagate(f.inOrg, f.member, age.ID); !errors.Is(err, ErrInsufficientOrganizationRole) {
+		t.Errorf("propagate by a member: err = %v, want ErrInsufficientOrganizationRole", err)
+	}
+
+	result, err := f.svc.Propagate(f.inOrg, f.admin, age.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.UpdatedForms) != 1 || result.UpdatedForms[0] != draft.ID || result.Skipped != 1 {
+		t.Errorf("result = %+v, want the draft updated and the published form skipped", result)
+	}
+	if q := f.library.formQuestions(draft.ID)[0]; q.Title != title || *q.LibraryVersion != 2 {
+		t.Errorf("draft question = %+v, want version 2", q)
+	}
+	if q := f.library.formQuestions(published.ID)[0]; q.Title != "Age group" || *q.LibraryVersion != 1 {
+		t.Errorf("published question = %+v, want it unchanged", q)
+	}
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/access"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/secretbox"
+)
+
+var (
+	// ErrInvalidChannel is returned for notification channels that can't
+	// be saved
+	ErrInvalidChannel = errors.New("invalid notification channel")
+	// ErrChannelNotFound is returned for channels the form does not have
+	ErrChannelNotFound = errors.New("notification channel not found")
+)
+
+// CreateChannelRequest adds a Slack or Teams channel to a form
+type CreateChannelRequest struct {
+	Type models.ChannelType `json:"type" binding:"required" example:"slack"`
+	Name string             `json:"name" binding:"required" example:"#feedback"`
+	// WebhookURL is the incoming webhook of the channel. It is stored
+	// encrypted and never returned.
+	WebhookURL string `json:"webhook_url" binding:"required" example:"https://hooks.slack.com/services/T000/B000/XXXX"`
+	// EventTypes are the form events posted, all of them by default
+	EventTypes []string `json:"event_types" example:"form.response.created"`
+	// QuietHoursStart and QuietHoursEnd, as HH:MM in Timezone, bound the
+	// time of day nothing is posted
+	QuietHoursStart string `json:"quiet_hours_start" example:"22:00"`
+	QuietHoursEnd   string `json:"quiet_hours_end" example:"07:00"`
+	// Timezone is an IANA time zone, UTC by default
+	Timezone string `json:"timezone" example:"Europe/Berlin"`
+	// IncludeAnswerSummary posts the answers of responses, which are
+	// redacted otherwise
+	IncludeAnswerSummary bool `json:"include_answer_summary"`
+	// Enabled posts events to the channel; create it disabled to send a
+	// test message first
+	Enabled bool `json:"enabled"`
+}
+
+// UpdateChannelRequest changes the fields of a channel that are set
+type UpdateChannelRequest struct {
+	Name *string `json:"name,omitempty"`
+	// WebhookURL replaces the webhook of the channel
+	WebhookURL           *string   `json:"webhook_url,omitempty"`
+	EventTypes           *[]string `json:"event_types,omitempty"`
+	QuietHoursStart      *string   `json:"quiet_hours_start,omitempty"`
+	QuietHoursEnd        *string   `json:"quiet_hours_end,omitempty"`
+	Timezone             *string   `json:"timezone,omitempty"`
+	IncludeAnswerSummary *bool     `json:"include_answer_summary,omitempty"`
+	Enabled              *bool     `json:"enabled,omitempty"`
+}
+
+// ListChannels lists the channels of a form for the users who may manage
+// its notifications
+func (s *notificationService) ListChannels(ctx context.Context, formID, userID uuid.UUID) ([]*models.NotificationChannel, error) {
+	if _, err := s.guard.authorize(ctx, formID, userID, access.ManageNotifications); err != nil {
+		return nil, err
+	}
+	channels, err := s.channelRepo.ListByForm(ctx, formID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notification channels: %w", err)
+	}
+	return channels, nil
+}
+
+// CreateChannel adds a channel to a form, sealing its webhook URL
+func (s *notificationService) CreateChannel(ctx context.Context, formID, userID uuid.UUID, req CreateChannelRequest) (*models.NotificationChannel, error) {
+	if _, err := s.guard.authorize(ctx, formID, userID, access.ManageNotifications); err != nil {
+		return nil, err
+	}
+
+	channel := &models.NotificationChannel{
+		ID:                   uuid.New(),
+		FormID:               formID,
+		Type:                 req.Type,
+		Name:                 strings.TrimSpace(req.Name),
+		EventTypes:           req.EventTypes,
+		QuietHoursStart:      req.QuietHoursStart,
+		QuietHoursEnd:        req.QuietHoursEnd,
+		Timezone:             req.Timezone,
+		IncludeAnswerSummary: req.IncludeAnswerSummary,
+		Enabled:              req.Enabled,
+		CreatedBy:            userID,
+	}
+	if channel.EventTypes == nil {
+		channel.EventTypes = append([]string(nil), models.ChannelEventTypes...)
+	}
+	if channel.Timezone == "" {
+		channel.Timezone = "UTC"
+	}
+	if err := channel.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidChannel, err)
+	}
+	if err := s.setWebhook(channel, req.WebhookURL); err != nil {
+		return nil, err
+	}
+
+	err := s.channelRepo.Create(ctx, channel, models.MaxChannelsPerForm)
+	if errors.Is(err, repository.ErrTooManyChannels) {
+		return nil, fmt.Errorf("%w: a form cannot have more than %d channels", ErrInvalidChannel, models.MaxChannelsPerForm)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create notification channel: %w", err)
+	}
+	return channel, nil
+}
+
+// UpdateChannel changes a channel of a form
+func (s *notificationService) UpdateChannel(ctx context.Context, formID, channelID, userID uuid.UUID, req UpdateChannelRequest) (*models.NotificationChannel, error) {
+	channel, err := s.authorizedChannel(ctx, formID, channelID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		channel.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.EventTypes != nil {
+		channel.EventTypes = *req.EventTypes
+	}
+	if req.QuietHoursStart != nil {
+		channel.QuietHoursStart = *req.QuietHoursStart
+	}
+	if req.QuietHoursEnd != nil {
+		channel.QuietHoursEnd = *req.QuietHoursEnd
+	}
+	if req.Timezone != nil {
+		channel.Timezone = *req.Timezone
+	}
+	if req.IncludeAnswerSummary != nil {
+		channel.IncludeAnswerSummary = *req.IncludeAnswerSummary
+	}
+	if req.Enabled != nil {
+		channel.Enabled = *req.Enabled
+	}
+	if err := channel.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidChannel, err)
+	}
+	if req.WebhookURL != nil {
+		if err := s.setWebhook(channel, *req.WebhookURL); err != nil {
+			return nil, err
+		}
+	}
+
+	channel.UpdatedAt = time.Now()
+	if err := s.channelRepo.Update(ctx, channel); err != nil {
+		return nil, fmt.Errorf("failed to update notification channel: %w", err)
+	}
+	return channel, nil
+}
+
+// DeleteChannel removes a channel of a form
+func (s *notificationService) DeleteChannel(ctx context.Context, formID, channelID, userID uuid.UUID) error {
+	if _, err := s.guard.authorize(ctx, formID, userID, access.ManageNotifications); err != nil {
+		return err
+	}
+	err := s.channelRepo.Delete(ctx, formID, channelID)
+	if errors.Is(err, repository.ErrChannelNotFound) {
+		return ErrChannelNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete notification channel: %w", err)
+	}
+	return nil
+}
+
+// SendTestChannelMessage publishes a form.notification.channel_test event
+// for the channel
+func (s *notificationService) SendTestChannelMessage(ctx context.Context, formID, channelID, userID uuid.UUID) (*TestNotificationResponse, error) {
+	channel, err := s.authorizedChannel(ctx, formID, channelID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.publisher.Publish(ctx, events.NotificationChannelTest, formID.String(), map[string]interface{}{
+		"form_id":    formID.String(),
+		"channel_id": channel.ID.String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to request test message: %w", err)
+	}
+
+	return &TestNotificationResponse{
+		Message: "Test message queued",
+		To:      channel.WebhookHint,
+	}, nil
+}
+
+// setWebhook validates and seals the webhook URL of a channel
+func (s *notificationService) setWebhook(channel *models.NotificationChannel, webhookURL string) error {
+	webhookURL = strings.TrimSpace(webhookURL)
+	if err := models.ValidateWebhookURL(channel.Type, webhookURL); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidChannel, err)
+	}
+	sealed, err := secretbox.Seal(s.channelSecret, webhookURL)
+	if err != nil {
+		return fmt.Errorf("failed to seal webhook URL: %w", err)
+	}
+	channel.SealedWebhookURL = sealed
+	channel.WebhookHint = models.WebhookHint(webhookURL)
+	return nil
+}
+
+func (s *notificationService) authorizedChannel(ctx context.Context, formID, channelID, userID uuid.UUID) (*models.NotificationChannel, error) {
+	if _, err := s.guard.authorize(ctx, formID, userID, access.ManageNotifications); err != nil {
+		return nil, err
+	}
+	channel, err := s.channelRepo.Get(ctx, formID, channelID)
+	if errors.Is(err, repository.ErrChannelNotFound) {
+		return nil, ErrChannelNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification channel: %w", err)
+	}
+	return channel, nil
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/access"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/secretbox"
+)
+
+var (
+	// ErrFormNotFound is returned for forms that don't exist
+	ErrFormNotFound = errors.New("form not found")
+	// ErrNotFormOwner is returned when the user does not own the form
+	ErrNotFormOwner = errors.New("access denied: user does not own this form")
+	// ErrNoOwnerEmail is returned for test notifications of forms without an
+	// owner email
+	ErrNoOwnerEmail = errors.New("the form has no owner email to notify")
+)
+
+// NotificationService defines the interface for the email notifications of
+// forms and their Slack and Teams channels. Notifications are sent by the
+// notification worker of the event bus, which reads the settings of a form
+// through GetNotificationTarget.
+type NotificationService interface {
+	GetNotificationTarget(ctx context.Context, formID uuid.UUID) (*NotificationTarget, error)
+	// SendTestNotification asks the notification worker for a test email
+	// to the owner of the form
+	SendTestNotification(ctx context.Context, formID uuid.UUID, userID uuid.UUID) (*TestNotificationResponse, error)
+
+	ListChannels(ctx context.Context, formID, userID uuid.UUID) ([]*models.NotificationChannel, error)
+	CreateChannel(ctx context.Context, formID, userID uuid.UUID, req CreateChannelRequest) (*models.NotificationChannel, error)
+	UpdateChannel(ctx context.Context, formID, channelID, userID uuid.UUID, req UpdateChannelRequest) (*models.NotificationChannel, error)
+	DeleteChannel(ctx context.Context, formID, channelID, userID uuid.UUID) error
+	// SendTestChannelMessage asks the notification worker for a test
+	// message to a channel, enabled or not, so owners can check its webhook
+	SendTestChannelMessage(ctx context.Context, formID, channelID, userID uuid.UUID) (*TestNotificationResponse, error)
+}
+
+// NotificationTarget is what the notification worker needs to notify about a form
+type NotificationTarget struct {
+	FormID        uuid.UUID                   `json:"form_id"`
+	OwnerID       uuid.UUID                   `json:"owner_id"`
+	Title         string                      `json:"title"`
+	Status        models.FormStatus           `json:"status"`
+	Notifications models.NotificationSettings `json:"notifications"`
+	// Channels are the Slack and Teams channels of the form, with their
+	// webhook URLs opened
+	Channels []ChannelTarget `json:"channels,omitempty"`
+}
+
+// ChannelTarget is a notification channel as the notification worker posts to it
+type ChannelTarget struct {
+	ID                   uuid.UUID          `json:"id"`
+	Type                 models.ChannelType `json:"type"`
+	Name                 string             `json:"name"`
+	WebhookURL           string             `json:"webhook_url"`
+	EventTypes           []string           `json:"event_types"`
+	QuietHoursStart      string             `json:"quiet_hours_start,omitempty"`
+	QuietHoursEnd        string             `json:"quiet_hours_end,omitempty"`
+	Timezone             string             `json:"timezone"`
+	IncludeAnswerSummary bool               `json:"include_answer_summary"`
+	Enabled              bool               `json:"enabled"`
+}
+
+// TestNotificationResponse acknowledges a test notification request
+type TestNotificationResponse struct {
+	Message string `json:"message"`
+	To      string `json:"to"`
+}
+
+// notificationService implements NotificationService interface
+type notificationService struct {
+	formRepo    repository.FormRepository
+	guard       formGuard
+	channelRepo repository.NotificationChannelRepository
+	publisher   events.Publisher
+	// channelSecret seals the webhook URLs of channels
+	channelSecret string
+}
+
+// NewNotificationService creates a new notification service instance
+func NewNotificationService(formRepo repository.FormRepository, collaboratorRepo repository.CollaboratorRepository, orgRepo repository.OrganizationRepository, channelRepo repository.NotificationChannelRepository, publisher events.Publisher, channelSecret string) NotificationService {
+	return &notificationService{
+		formRepo:      formRepo,
+		guard:         formGuard{forms: formRepo, collaborators: collaboratorRepo, orgs: organizationScope{orgs: orgRepo}},
+		channelRepo:   channelRepo,
+		publisher:     publisher,
+		channelSecret: channelSecret,
+	}
+}
+
+// GetNotificationTarget returns the notification settings of a form
+func (s *notificationService) GetNotificationTarget(ctx context.Context, formID uuid.UUID) (*NotificationTarget, error) {
+	form, err := s.getForm(ctx, formID)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := form.GetSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	channels, err := s.channelRepo.ListByForm(ctx, form.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notification channels: %w", err)
+	}
+
+	target := &NotificationTarget{
+		FormID:        form.ID,
+		OwnerID:       form.UserID,
+		Title:         form.Title,
+		Status:        form.Status,
+		Notifications: settings.Notifications,
+	}
+	for _, channel := range channels {
+		webhookURL, err := secretbox.Open(s.channelSecret, channel.SealedWebhookURL)
+		if err != nil {
+			// Sealed with another secret: the owner must set the
+			// webhook again
+			log.Printf("Skipping notification channel %s of form %s: %v", channel.ID, form.ID, err)
+			continue
+		}
+		target.Channels = append(target.Channels, ChannelTarget{
+			ID:                   channel.ID,
+			Type:                 channel.Type,
+			Name:                 channel.Name,
+			WebhookURL:           webhookURL,
+			EventTypes:           channel.EventTypes,
+			QuietHoursStart:      channel.QuietHoursStart,
+			QuietHoursEnd:        channel.QuietHoursEnd,
+			Timezone:             channel.Timezone,
+			IncludeAnswerSummary: channel.IncludeAnswerSummary,
+			Enabled:              channel.Enabled,
+		})
+	}
+	return target, nil
+}
+
+// SendTestNotification publishes a form.notification.test event for the form
+func (s *notificationService) SendTestNotification(ctx context.Context, formID uuid.UUID, userID uuid.UUID) (*TestNotificationResponse, error) {
+	form, err := s.guard.authorize(ctx, formID, userID, access.ManageNotifications)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := form.GetSettings()
+	if err != nil {
+		return nil, err
+	}
+	if settings.Notifications.OwnerEmail == "" {
+		return nil, ErrNoOwnerEmail
+	}
+
+	err = s.publisher.Publish(ctx, events.NotificationTest, form.ID.String(), map[string]interface{}{
+		"form_id":  form.ID.String(),
+		"owner_id": form.UserID.String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to request test notification: %w", err)
+	}
+
+	return &TestNotificationResponse{
+		Message: "Test notification queued",
+		To:      settings.Notifications.OwnerEmail,
+	}, nil
+}
+
+func (s *notificationService) getForm(ctx context.Context, formID uuid.UUID) (*models.Form, error) {
+	form, err := s.formRepo.GetByID(ctx, formID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrFormNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get form: %w", err)
+	}
+	return form, nil
+}
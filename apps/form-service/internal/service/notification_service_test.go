@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+)
+
+// memoryChannelRepository keeps notification channels in memory
+type memoryChannelRepository struct {
+	mu       sync.Mutex
+	channels map[uuid.UUID]*models.NotificationChannel
+}
+
+func newMemoryChannelRepository() *memoryChannelRepository {
+	return &memoryChannelRepository{channels: make(map[uuid.UUID]*models.NotificationChannel)}
+}
+
+func (r *memoryChannelRepository) Create(_ context.Context, channel *models.NotificationChannel, max int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count := 0
+	for _, c := range r.channels {
+		if c.FormID == channel.FormID {
+			count++
+		}
+	}
+	if count >= max {
+		return repository.ErrTooManyChannels
+	}
+	channel.CreatedAt = time.Now()
+	stored := *channel
+	r.channels[channel.ID] = &stored
+	return nil
+}
+
+func (r *memoryChannelRepository) Get(_ context.Context, formID, id uuid.UUID) (*models.NotificationChannel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	channel, ok := r.channels[id]
+	if !ok || channel.FormID != formID {
+		return nil, repository.ErrChannelNotFound
+	}
+	stored := *channel
+	return &stored, nil
+}
+
+func (r *memoryChannelRepository) ListByForm(_ context.Context, formID uuid.UUID) ([]*models.NotificationChannel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	channels := []*models.NotificationChannel{}
+	for _, channel := range r.channels {
+		if channel.FormID == formID {
+			stored := *channel
+			channels = append(channels, &stored)
+		}
+	}
+	sort.Slice(channels, func(i, j int) bool { return channels[i].CreatedAt.Before(channels[j].CreatedAt) })
+	return channels, nil
+}
+
+func (r *memoryChannelRepository) Update(_ context.Context, channel *models.NotificationChannel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *channel
+	r.channels[channel.ID] = &stored
+	return nil
+}
+
+func (r *memoryChannelRepository) Delete(_ context.Context, formID, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if channel, ok := r.channels[id]; !ok || channel.FormID != formID {
+		return repository.ErrChannelNotFound
+	}
+	delete(r.channels, id)
+	return nil
+}
+
+func TestSendTestNotification(t *testing.T) {
+	ctx := context.Background()
+	repos := newMemoryStore(time.Now())
+	publisher := &recordingPublisher{}
+	svc := NewNotificationService(repos.forms, repos.collaborators, repos.orgs, newMemoryChannelRepository(), publisher, "secret")
+
+	owner := uuid.New()
+	form := repos.createNotifiedForm(t, owner, models.NotificationSettings{
+		NotifyOwnerOnResponse: true,
+		OwnerEmail:            "owner@example.com",
+	})
+
+	resp, err := svc.SendTestNotification(ctx, form.ID, owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.To != "owner@example.com" {
+		t.Errorf("test notification sent to %q, want the owner email", resp.To)
+	}
+	if len(publisher.events) != 1 || publisher.events[0] != events.NotificationTest || publisher.keys[0] != form.ID.String() {
+		t.Errorf("published %v keyed %v, want one %s event keyed by the form", publisher.events, publisher.keys, events.NotificationTest)
+	}
+
+	if _, err := svc.SendTestNotification(ctx, form.ID, uuid.New()); !errors.Is(err, ErrNotFormOwner) {
+		t.Errorf("test notification by another user: err = %v, want ErrNotFormOwner", err)
+	}
+	if _, err := svc.SendTestNotification(ctx, uuid.New(), owner); !errors.Is(err, ErrFormNotFound) {
+		t.Errorf("test notification of a missing form: err = %v, want ErrFormNotFound", err)
+	}
+
+	silent := repos.createNotifiedForm(t, owner, models.NotificationSettings{})
+	if _, err := svc.SendTestNotification(ctx, silent.ID, owner); !errors.Is(err, ErrNoOwnerEmail) {
+		t.Errorf("test notification without an owner email: err = %v, want ErrNoOwnerEmail", err)
+	}
+	if len(publisher.events) != 1 {
+		t.Errorf("rejected test notifications published events: %v", publisher.events)
+	}
+}
+
+func TestGetNotificationTarget(t *testing.T) {
+	repos := newMemoryStore(time.Now())
+	svc := NewNotificationService(repos.forms, repos.collaborators, repos.orgs, newMemoryChannelRepository(), &recordingPublisher{}, "secret")
+
+	owner := uuid.New()
+	notifications := models.NotificationSettings{
+		SendRespondentCopy: true,
+		ReplyTo:            "support@example.com",
+	}
+	form := repos.createNotifiedForm(t, owner, notifications)
+
+	target, err := svc.GetNotificationTarget(context.Background(), form.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := NotificationTarget{
+		FormID:        form.ID,
+		OwnerID:       owner,
+		Title:         "Feedback",
+		Status:        models.FormStatusPublished,
+		Notifications: notifications,
+	}
+	if !reflect.DeepEqual(*target, want) {
+		t.Errorf("target = %+v, want %+v", *target, want)
+	}
+}
+
+func TestNotificationChannels(t *testing.T) {
+	ctx := context.Background()
+	repos := newMemoryStore(time.Now())
+	channels := newMemoryChannelRepository()
+	publisher := &recordingPublisher{}
+	svc := NewNotificationService(repos.forms, repos.collaborators, repos.orgs, channels, publisher, "secret")
+
+	owner := uuid.New()
+	form := repos.createNotifiedForm(t, owner, models.NotificationSettings{})
+	const webhook = "https://hooks.slack.com/services/T000/B000/abcd1234"
+
+	channel, err := svc.CreateChannel(ctx, form.ID, owner, CreateChannelRequest{
+		Type:            models.ChannelTypeSlack,
+		Name:            "#feedback",
+		WebhookURL:      webhook,
+		QuietHoursStart: "22:00",
+		QuietHoursEnd:   "07:00",
+		Timezone:        "Europe/Berlin",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(channel.SealedWebhookURL, "hooks.slack.com") || channel.WebhookHint != "hooks.slack.com/…1234" {
+		t.Errorf("sealed %q with hint %q, want the URL sealed and hinted", channel.SealedWebhookURL, channel.WebhookHint)
+	}
+	if !reflect.DeepEqual([]string(channel.EventTypes), models.ChannelEventTypes) || channel.Enabled {
+		t.Errorf("channel = %+v, want every event type, disabled", channel)
+	}
+
+	for name, req := range map[string]CreateChannelRequest{
+		"webhook of another host": {Type: models.ChannelTypeSlack, Name: "x", WebhookURL: "https://internal.example/hook"},
+		"slack webhook for teams": {Type: models.ChannelTypeTeams, Name: "x", WebhookURL: webhook},
+		"plain http webhook":      {Type: models.ChannelTypeSlack, Name: "x", WebhookURL: "http://hooks.slack.com/services/T/B/X"},
+		"unknown event type":      {Type: models.ChannelTypeSlack, Name: "x", WebhookURL: webhook, EventTypes: []string{"form.deleted"}},
+		"half quiet hours":        {Type: models.ChannelTypeSlack, Name: "x", WebhookURL: webhook, QuietHoursStart: "22:00"},
+	} {
+		if _, err := svc.CreateChannel(ctx, form.ID, owner, req); !errors.Is(err, ErrInvalidChannel) {
+			t.Errorf("%s: err = %v, want ErrInvalidChannel", name, err)
+		}
+	}
+	if _, err := svc.CreateChannel(ctx, form.ID, uuid.New(), CreateChannelRequest{Type: models.ChannelTypeSlack, Name: "x", WebhookURL: webhook}); !errors.Is(err, ErrNotFormOwner) {
+		t.Errorf("channel created by another user: err = %v, want ErrNotFormOwner", err)
+	}
+
+	manager, editor := uuid.New(), uuid.New()
+	for userID, role := range map[uuid.UUID]models.CollaboratorRole{manager: models.CollaboratorRoleManager, editor: models.CollaboratorRoleEditor} {
+		if err := repos.collaborators.Create(ctx, &models.Collaborator{FormID: form.ID, UserID: &userID, Role: role, Status: models.CollaboratorStatusActive}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := svc.CreateChannel(ctx, form.ID, editor, CreateChannelRequest{Type: models.ChannelTypeSlack, Name: "x", WebhookURL: webhook}); !errors.Is(err, ErrInsufficientRole) {
+		t.Errorf("channel created by an editor: err = %v, want ErrInsufficientRole", err)
+	}
+	if listed, err := svc.ListChannels(ctx, form.ID, manager); err != nil || len(listed) != 1 || listed[0].ID != channel.ID {
+		t.Errorf("channels listed for a manager = %v (err %v), want the channel", listed, err)
+	}
+
+	enabled := true
+	teams := "https://acme.webhook.office.com/webhookb2/abc/IncomingWebhook/def/ghij"
+	if _, err := svc.UpdateChannel(ctx, form.ID, channel.ID, owner, UpdateChannelRequest{WebhookURL: &teams}); !errors.Is(err, ErrInvalidChannel) {
+		t.Errorf("Teams webhook on a Slack channel: err = %v, want ErrInvalidChannel", err)
+	}
+	if _, err := svc.UpdateChannel(ctx, form.ID, channel.ID, owner, UpdateChannelRequest{Enabled: &enabled}); err != nil {
+		t.Fatal(err)
+	}
+
+	target, err := svc.GetNotificationTarget(ctx, form.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(target.Channels) != 1 || target.Channels[0].WebhookURL != webhook || !target.Channels[0].Enabled ||
+		target.Channels[0].Timezone != "Europe/Berlin" || target.Channels[0].QuietHoursStart != "22:00" {
+		t.Errorf("target channels = %+v, want the enabled channel with its webhook opened", target.Channels)
+	}
+
+	// Channels sealed with another secret are left out
+	other := NewNotificationService(repos.forms, repos.collaborators, repos.orgs, channels, publisher, "rotated")
+	if target, err := other.GetNotificationTarget(ctx, form.ID); err != nil || len(target.Channels) != 0 {
+		t.Errorf("target under another secret = %+v, %v; want no channels", target, err)
+	}
+
+	resp, err := svc.SendTestChannelMessage(ctx, form.ID, channel.ID, owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.To != channel.WebhookHint || len(publisher.events) != 1 || publisher.events[0] != events.NotificationChannelTest ||
+		publisher.data[0]["channel_id"] != channel.ID.String() {
+		t.Errorf("test message to %q published %v %v, want one %s event for the channel", resp.To, publisher.events, publisher.data, events.NotificationChannelTest)
+	}
+	if _, err := svc.SendTestChannelMessage(ctx, form.ID, uuid.New(), owner); !errors.Is(err, ErrChannelNotFound) {
+		t.Errorf("test message to a missing channel: err = %v, want ErrChannelNotFound", err)
+	}
+
+	if err := svc.DeleteChannel(ctx, form.ID, channel.ID, owner); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.DeleteChannel(ctx, form.ID, channel.ID, owner); !errors.Is(err, ErrChannelNotFound) {
+		t.Errorf("deleting twice: err = %v, want ErrChannelNotFound", err)
+	}
+}
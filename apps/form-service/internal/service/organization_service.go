@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/tenant"
+)
+
+var (
+	// ErrOrganizationNotFound is returned for organizations that don't exist
+	// and for those the user is not a member of, so that other organizations
+	// can't be told apart from missing ones
+	ErrOrganizationNotFound = errors.New("organization not found")
+	// ErrInsufficientOrganizationRole is returned when the role of a member
+	// does not allow what they attempted
+	ErrInsufficientOrganizationRole = errors.New("access denied: your role in this organization does not allow this")
+	// ErrMemberExists is returned when the user already is a member
+	ErrMemberExists = errors.New("user already is a member of this organization")
+	// ErrInvalidOrganization is returned for organizations and members that
+	// can't be created
+	ErrInvalidOrganization = errors.New("invalid organization")
+)
+
+// OrganizationService defines the interface for organizations and their
+// members. Members see the organization and its members; owners and admins
+// also invite members.
+type OrganizationService interface {
+	CreateOrganization(ctx context.Context, userID uuid.UUID, req CreateOrganizationRequest) (*models.Organization, error)
+	ListOrganizations(ctx context.Context, userID uuid.UUID) ([]*models.Organization, error)
+	InviteMember(ctx context.Context, orgID, userID uuid.UUID, req InviteMemberRequest) (*models.OrganizationMember, error)
+	ListMembers(ctx context.Context, orgID, userID uuid.UUID) ([]*models.OrganizationMember, error)
+}
+
+// CreateOrganizationRequest represents a request to create an organization
+type CreateOrganizationRequest struct {
+	Name string `json:"name" binding:"required,max=200" example:"Acme Research"`
+}
+
+// InviteMemberRequest adds a user to an organization with a role
+type InviteMemberRequest struct {
+	UserID uuid.UUID               `json:"user_id" binding:"required" example:"5f0c6a3e-8a44-4c1f-9d43-2b6f3f3f8c11"`
+	Email  string                  `json:"email" binding:"required,email" example:"ada@example.com"`
+	Role   models.OrganizationRole `json:"role" binding:"required" enums:"member,admin" example:"member"`
+}
+
+// organizationService implements OrganizationService interface
+type organizationService struct {
+	orgRepo repository.OrganizationRepository
+	auditor events.Auditor
+	now     func() time.Time
+}
+
+// NewOrganizationService creates a new organization service instance.
+// Invitations are recorded to auditor.
+func NewOrganizationService(orgRepo repository.OrganizationRepository, auditor events.Auditor) OrganizationService {
+	return &organizationService{
+		orgRepo: orgRepo,
+		auditor: auditor,
+		now:     time.Now,
+	}
+}
+
+// CreateOrganization creates an organization owned by the user
+func (s *organizationService) CreateOrganization(ctx context.Context, userID uuid.UUID, req CreateOrganizationRequest) (*models.Organization, error) {
+	org := &models.Organization{Name: strings.TrimSpace(req.Name), CreatedBy: userID}
+	if err := org.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidOrganization, err)
+	}
+
+	owner := &models.OrganizationMember{
+		UserID:   userID,
+		Role:     models.OrganizationRoleOwner,
+		JoinedAt: s.now(),
+	}
+	if err := s.orgRepo.Create(ctx, org, owner); err != nil {
+		return nil, fmt.Errorf("failed to create organization: %w", err)
+	}
+	return org, nil
+}
+
+// ListOrganizations lists the organizations of the user, their personal
+// organization first
+func (s *organizationService) ListOrganizations(ctx context.Context, userID uuid.UUID) ([]*models.Organization, error) {
+	personal, err := s.orgRepo.Personal(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get personal organization: %w", err)
+	}
+
+	orgs, err := s.orgRepo.ListForUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+
+	listed := []*models.Organization{personal}
+	for _, org := range orgs {
+		if org.ID != personal.ID {
+			listed = append(listed, org)
+		}
+	}
+	return listed, nil
+}
+
+// InviteMember adds a user to the organization. The invited user joins
+// right away, identified by the user ID of their token.
+func (s *organizationService) InviteMember(ctx context.Context, orgID, userID uuid.UUID, req InviteMemberRequest) (*models.OrganizationMember, error) {
+	if !req.Role.IsValid() {
+		return nil, fmt.Errorf("%w: invalid role: %s", ErrInvalidOrganization, req.Role)
+	}
+
+	inviter, err := s.getMember(ctx, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !inviter.Role.CanInvite() {
+		return nil, ErrInsufficientOrganizationRole
+	}
+
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	if org.Personal {
+		return nil, fmt.Errorf("%w: personal organizations have no other members", ErrInvalidOrganization)
+	}
+
+	invitedBy := userID
+	member := &models.OrganizationMember{
+		OrganizationID: orgID,
+		UserID:         req.UserID,
+		Email:          req.Email,
+		Role:           req.Role,
+		InvitedBy:      &invitedBy,
+		JoinedAt:       s.now(),
+	}
+	added, err := s.orgRepo.AddMember(ctx, member)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add member: %w", err)
+	}
+	if !added {
+		return nil, ErrMemberExists
+	}
+
+	s.auditor.Record(ctx, events.AuditEvent{
+		Action:       events.AuditOrganizationMemberAdded,
+		Actor:        userID.String(),
+		ResourceType: "organization",
+		ResourceID:   orgID.String(),
+		After: map[string]interface{}{
+			"user_id": member.UserID.String(),
+			"role":    member.Role,
+		},
+	})
+	return member, nil
+}
+
+// ListMembers lists the members of the organization
+func (s *organizationService) ListMembers(ctx context.Context, orgID, userID uuid.UUID) ([]*models.OrganizationMember, error) {
+	if _, err := s.getMember(ctx, orgID, userID); err != nil {
+		return nil, err
+	}
+
+	members, err := s.orgRepo.ListMembers(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members: %w", err)
+	}
+	return members, nil
+}
+
+// getMember gets the membership of the user, hiding the organizations they
+// are not a member of
+func (s *organizationService) getMember(ctx context.Context, orgID, userID uuid.UUID) (*models.OrganizationMember, error) {
+	member, err := s.orgRepo.GetMember(ctx, orgID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get member: %w", err)
+	}
+	return member, nil
+}
+
+// organizationScope resolves the organization a request acts in
+type organizationScope struct {
+	orgs repository.OrganizationRepository
+}
+
+// resolve returns the organization ctx acts in once the user is found to be
+// a member of it. ok is false for requests acting in no organization.
+func (s organizationScope) resolve(ctx context.Context, userID uuid.UUID) (orgID uuid.UUID, ok bool, err error) {
+	orgID, ok = tenant.Organization(ctx)
+	if !ok {
+		return uuid.Nil, false, nil
+	}
+
+	_, err = s.orgs.GetMember(ctx, orgID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return uuid.Nil, false, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return uuid.Nil, false, fmt.Errorf("failed to get member: %w", err)
+	}
+	return orgID, true, nil
+}
+
+// home returns the organization the new forms of the user go in: the one
+// ctx acts in, or else the personal organization of the user
+func (s organizationScope) home(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
+	orgID, ok, err := s.resolve(ctx, userID)
+	if err != nil || ok {
+		return orgID, err
+	}
+
+	personal, err := s.orgs.Personal(ctx, userID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to get personal organization: %w", err)
+	}
+	return personal.ID, nil
+}
+
+// inScope reports whether the form belongs to the organization ctx acts in,
+// always true for requests acting in none
+func inScope(ctx context.Context, form *models.Form) bool {
+	orgID, ok := tenant.Organization(ctx)
+	return !ok || form.OrganizationID == orgID
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/tenant"
+)
+
+func TestOrganizationMembers(t *testing.T) {
+	ctx := context.Background()
+	auditor := &recordingAuditor{}
+	svc := NewOrganizationService(newMemoryOrganizationRepository(), auditor)
+	owner, admin, member, stranger := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	org, err := svc.CreateOrganization(ctx, owner, CreateOrganizationRequest{Name: " Acme "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if org.Name != "Acme" {
+		t.Errorf("name = %q, want it trimmed", org.Name)
+	}
+
+	invite := func(by, userID uuid.UUID, role models.OrganizationRole) error {
+		_, err := svc.InviteMember(ctx, org.ID, by, InviteMemberRequest{UserID: userID, Email: "member@example.com", Role: role})
+		return err
+	}
+	if err := invite(owner, admin, models.OrganizationRoleAdmin); err != nil {
+		t.Fatalf("owner invites an admin: %v", err)
+	}
+	if err := invite(admin, member, models.OrganizationRoleMember); err != nil {
+		t.Fatalf("admin invites a member: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"member can't invite", invite(member, uuid.New(), models.OrganizationRoleMember), ErrInsufficientOrganizationRole},
+		{"stranger can't invite", invite(stranger, uuid.New(), models.OrganizationRoleMember), ErrOrganizationNotFound},
+		{"members are invited once", invite(owner, member, models.OrganizationRoleAdmin), ErrMemberExists},
+		{"owner is never granted", invite(owner, uuid.New(), models.OrganizationRoleOwner), ErrInvalidOrganization},
+	}
+	for _, tt := range tests {
+		if !errors.Is(tt.err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, tt.err, tt.want)
+		}
+	}
+
+	members, err := svc.ListMembers(ctx, org.ID, member)
+	if err != nil || len(members) != 3 {
+		t.Errorf("ListMembers = %d members, %v; want 3", len(members), err)
+	}
+	if _, err := svc.ListMembers(ctx, org.ID, stranger); !errors.Is(err, ErrOrganizationNotFound) {
+		t.Errorf("stranger lists members: err = %v, want ErrOrganizationNotFound", err)
+	}
+
+	orgs, err := svc.ListOrganizations(ctx, member)
+	if err != nil || len(orgs) != 2 || !orgs[0].Personal || orgs[1].ID != org.ID {
+		t.Errorf("ListOrganizations = %v, %v; want the personal organization, then Acme", orgs, err)
+	}
+	personal := orgs[0]
+	if _, err := svc.InviteMember(ctx, personal.ID, member, InviteMemberRequest{UserID: uuid.New(), Role: models.OrganizationRoleMember}); !errors.Is(err, ErrInvalidOrganization) {
+		t.Errorf("invite to a personal organization: err = %v, want ErrInvalidOrganization", err)
+	}
+
+	if got := auditor.actions(); len(got) != 2 || got[0] != events.AuditOrganizationMemberAdded {
+		t.Errorf("audited %v, want both invitations", got)
+	}
+}
+
+// TestFormsScopedToOrganization checks that a request acting in an
+// organization only finds its forms, and that requests acting in none keep
+// their access
+func TestFormsScopedToOrganization(t *testing.T) {
+	ctx := context.Background()
+	repos := newMemoryStore(time.Now())
+	orgs := NewOrganizationService(repos.orgs, events.LogAuditor{})
+	forms := repos.formService()
+	user, outsider := uuid.New(), uuid.New()
+
+	acme, err := orgs.CreateOrganization(ctx, user, CreateOrganizationRequest{Name: "Acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	globex, err := orgs.CreateOrganization(ctx, user, CreateOrganizationRequest{Name: "Globex"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inAcme, inGlobex := tenant.WithOrganization(ctx, acme.ID), tenant.WithOrganization(ctx, globex.ID)
+
+	form, err := forms.CreateForm(inAcme, user, CreateFormRequest{Title: "Acme survey"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if form.OrganizationID != acme.ID {
+		t.Errorf("form created in %s, want Acme", form.OrganizationID)
+	}
+	legacy, err := forms.CreateForm(ctx, user, CreateFormRequest{Title: "Personal survey"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	personal, _ := repos.orgs.Personal(ctx, user)
+	if legacy.OrganizationID != personal.ID {
+		t.Errorf("form created without an organization went in %s, want the personal organization", legacy.OrganizationID)
+	}
+
+	get := func(ctx context.Context, userID uuid.UUID) error {
+		_, err := forms.GetForm(ctx, form.ID, userID)
+		return err
+	}
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"same organization", get(inAcme, user), nil},
+		{"other organization", get(inGlobex, user), ErrFormNotFound},
+		{"no organization", get(ctx, user), nil},
+		{"not a member", get(tenant.WithOrganization(ctx, acme.ID), outsider), ErrOrganizationNotFound},
+	}
+	for _, tt := range tests {
+		if tt.want == nil && tt.err != nil {
+			t.Errorf("%s: err = %v, want none", tt.name, tt.err)
+		}
+		if tt.want != nil && !errors.Is(tt.err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, tt.err, tt.want)
+		}
+	}
+
+	if err := forms.DeleteForm(inGlobex, form.ID, user); !errors.Is(err, ErrFormNotFound) {
+		t.Errorf("delete from another organization: err = %v, want ErrFormNotFound", err)
+	}
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/access"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/preview"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+)
+
+var (
+	// ErrInvalidPreviewExpiry is returned for preview tokens requested to
+	// expire after the maximum the service allows
+	ErrInvalidPreviewExpiry = errors.New("invalid preview token expiry")
+	// ErrPreviewTokenInvalid is returned for preview tokens that are
+	// malformed, expired or revoked
+	ErrPreviewTokenInvalid = errors.New("preview token is invalid, expired or revoked")
+)
+
+// PreviewService defines the interface for sharing draft forms with people
+// without accounts. Editors of a form issue, list and revoke its preview
+// tokens; anyone holding an active token reads the current definition of the
+// form, but cannot submit responses to it.
+type PreviewService interface {
+	IssueToken(ctx context.Context, formID, userID uuid.UUID, req IssuePreviewTokenRequest) (*IssuedPreviewToken, error)
+	ListTokens(ctx context.Context, formID, userID uuid.UUID) ([]*models.PreviewToken, error)
+	RevokeToken(ctx context.Context, formID, userID, tokenID uuid.UUID) (*models.PreviewToken, error)
+	GetPreview(ctx context.Context, token string) (*FormPreview, error)
+}
+
+// IssuePreviewTokenRequest represents a request to issue a preview token.
+// Tokens expire after 7 days, or the maximum allowed when it is shorter,
+// unless ExpiresIn asks for less.
+type IssuePreviewTokenRequest struct {
+	// ExpiresIn is how long the token stays valid, in seconds
+	ExpiresIn int `json:"expires_in,omitempty" binding:"omitempty,min=60" example:"86400"`
+}
+
+// IssuedPreviewToken is a preview token with its record. The token is not
+// stored and only returned when it is issued.
+type IssuedPreviewToken struct {
+	*models.PreviewToken
+	Token string `json:"token"`
+	// PreviewURL is the path of the preview, relative to the API Gateway
+	PreviewURL string `json:"preview_url" example:"/api/v1/public/forms/preview?token=..."`
+}
+
+// FormPreview is the current definition of a form shared with a preview
+// token. Clients render it with a preview banner and without a submit
+// action.
+type FormPreview struct {
+	// Preview is always true, marking the definition as a preview
+	Preview bool `json:"preview" example:"true"`
+	// ReadOnly is always true: responses to previews are rejected
+	ReadOnly  bool               `json:"read_only" example:"true"`
+	Form      *models.Form       `json:"form"`
+	Questions []*models.Question `json:"questions"`
+	// ExpiresAt is when the token expires
+	ExpiresAt time.Time `json:"expires_at"`
+	// UpdatedSinceIssued reports whether the form was updated after the
+	// token was issued
+	UpdatedSinceIssued bool `json:"updated_since_issued"`
+}
+
+// previewService implements PreviewService interface
+type previewService struct {
+	tokens       repository.PreviewTokenRepository
+	formRepo     repository.FormRepository
+	questionRepo repository.QuestionRepository
+	guard        formGuard
+	auditor      events.Auditor
+	config       preview.Config
+	now          func() time.Time
+}
+
+// NewPreviewService creates a new preview service instance. Tokens are
+// signed with the secret of config, issued by the users collaborators allow
+// to edit the form, and their issue and revocation recorded to auditor.
+func NewPreviewService(tokens repository.PreviewTokenRepository, formRepo repository.FormRepository, questionRepo repository.QuestionRepository, collaboratorRepo repository.CollaboratorRepository, orgRepo repository.OrganizationRepository, auditor events.Auditor, config preview.Config) PreviewService {
+	return &previewService{
+		tokens:       tokens,
+		formRepo:     formRepo,
+		questionRepo: questionRepo,
+		guard:        formGuard{forms: formRepo, collaborators: collaboratorRepo, orgs: organizationScope{orgs: orgRepo}},
+		auditor:      auditor,
+		config:       config,
+		now:          time.Now,
+	}
+}
+
+// IssueToken issues a token sharing the form. Deleted forms are not found.
+func (s *previewService) IssueToken(ctx context.Context, formID, userID uuid.UUID, req IssuePreviewTokenRequest) (*IssuedPreviewToken, error) {
+	ttl := models.DefaultPreviewTokenTTL
+	if s.config.MaxTTL < ttl {
+		ttl = s.config.MaxTTL
+	}
+	if req.ExpiresIn > 0 {
+		ttl = time.Duration(req.ExpiresIn) * time.Second
+		if ttl > s.config.MaxTTL {
+			return nil, fmt.Errorf("%w: tokens expire within %s", ErrInvalidPreviewExpiry, s.config.MaxTTL)
+		}
+	}
+
+	form, err := s.guard.authorize(ctx, formID, userID, access.Edit)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	record := &models.PreviewToken{
+		ID:        uuid.New(),
+		FormID:    form.ID,
+		CreatedBy: userID,
+		ExpiresAt: now.Add(ttl).Truncate(time.Second),
+	}
+	token, err := preview.Sign(s.config.Secret, preview.Claims{
+		TokenID:   record.ID,
+		FormID:    form.ID,
+		Version:   form.UpdatedAt.UnixMilli(),
+		Scope:     preview.Scope,
+		ExpiresAt: record.ExpiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign preview token: %w", err)
+	}
+	if err := s.tokens.Create(ctx, record); err != nil {
+		return nil, fmt.Errorf("failed to create preview token: %w", err)
+	}
+
+	s.auditor.Record(ctx, events.AuditEvent{
+		Action:       events.AuditPreviewTokenIssued,
+		Actor:        userID.String(),
+		ResourceType: "form",
+		ResourceID:   form.ID.String(),
+		After: map[string]interface{}{
+			"token_id":   record.ID.String(),
+			"expires_at": record.ExpiresAt,
+		},
+	})
+
+	return &IssuedPreviewToken{
+		PreviewToken: record,
+		Token:        token,
+		PreviewURL:   "/api/v1/public/forms/preview?token=" + url.QueryEscape(token),
+	}, nil
+}
+
+// ListTokens lists the preview tokens of the form, revoked and expired ones
+// included, most recent first
+func (s *previewService) ListTokens(ctx context.Context, formID, userID uuid.UUID) ([]*models.PreviewToken, error) {
+	if _, err := s.guard.authorize(ctx, formID, userID, access.Edit); err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.tokens.ListByForm(ctx, formID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list preview tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// RevokeToken revokes a preview token of the form. Revoking a revoked token
+// changes nothing.
+func (s *previewService) RevokeToken(ctx context.Context, formID, userID, tokenID uuid.UUID) (*models.PreviewToken, error) {
+	if _, err := s.guard.authorize(ctx, formID, userID, access.Edit); err != nil {
+		return nil, err
+	}
+
+	token, err := s.tokens.Revoke(ctx, formID, tokenID, s.now())
+	if err != nil {
+		return nil, err
+	}
+
+	s.auditor.Record(ctx, events.AuditEvent{
+		Action:       events.AuditPreviewTokenRevoked,
+		Actor:        userID.String(),
+		ResourceType: "form",
+		ResourceID:   formID.String(),
+		Before:       map[string]interface{}{"token_id": token.ID.String()},
+	})
+	return token, nil
+}
+
+// GetPreview returns the current definition of the form shared by an active
+// token. Forms deleted since the token was issued are not found.
+func (s *previewService) GetPreview(ctx context.Context, token string) (*FormPreview, error) {
+	now := s.now()
+	claims, err := preview.Verify(s.config.Secret, token, now)
+	if err != nil {
+		return nil, ErrPreviewTokenInvalid
+	}
+
+	record, err := s.tokens.Get(ctx, claims.TokenID)
+	if errors.Is(err, repository.ErrPreviewTokenNotFound) {
+		return nil, ErrPreviewTokenInvalid
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get preview token: %w", err)
+	}
+	if record.FormID != claims.FormID || !record.Active(now) {
+		return nil, ErrPreviewTokenInvalid
+	}
+
+	form, err := s.formRepo.GetByID(ctx, claims.FormID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrFormNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get form: %w", err)
+	}
+	questions, err := s.questionRepo.GetByFormID(ctx, form.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get questions: %w", err)
+	}
+
+	return &FormPreview{
+		Preview:            true,
+		ReadOnly:           true,
+		Form:               form,
+		Questions:          questions,
+		ExpiresAt:          record.ExpiresAt,
+		UpdatedSinceIssued: form.UpdatedAt.UnixMilli() > claims.Version,
+	}, nil
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/preview"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+)
+
+// memoryPreviewTokens keeps preview tokens in memory
+type memoryPreviewTokens struct {
+	mu     sync.Mutex
+	tokens map[uuid.UUID]models.PreviewToken
+}
+
+func (r *memoryPreviewTokens) Create(_ context.Context, token *models.PreviewToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens[token.ID] = *token
+	return nil
+}
+
+func (r *memoryPreviewTokens) Get(_ context.Context, id uuid.UUID) (*models.PreviewToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.tokens[id]
+	if !ok {
+		return nil, repository.ErrPreviewTokenNotFound
+	}
+	return &token, nil
+}
+
+func (r *memoryPreviewTokens) ListByForm(_ context.Context, formID uuid.UUID) ([]*models.PreviewToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var tokens []*models.PreviewToken
+	for _, token := range r.tokens {
+		if token.FormID == formID {
+			token := token
+			tokens = append(tokens, &token)
+		}
+	}
+	return tokens, nil
+}
+
+func (r *memoryPreviewTokens) Revoke(_ context.Context, formID, id uuid.UUID, now time.Time) (*models.PreviewToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.tokens[id]
+	if !ok || token.FormID != formID {
+		return nil, repository.ErrPreviewTokenNotFound
+	}
+	if token.RevokedAt == nil {
+		token.RevokedAt = &now
+		r.tokens[id] = token
+	}
+	return &token, nil
+}
+
+func newPreviewFixture(t *testing.T, maxTTL time.Duration) (*previewService, *memoryStore) {
+	t.Helper()
+	repos := newMemoryStore(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	svc := NewPreviewService(&memoryPreviewTokens{tokens: make(map[uuid.UUID]models.PreviewToken)},
+		repos.forms, repos.questions, nil, repos.orgs, &recordingAuditor{},
+		preview.Config{Secret: "secret", MaxTTL: maxTTL}).(*previewService)
+	svc.now = repos.clock.now
+	return svc, repos
+}
+
+func TestPreviewTokens(t *testing.T) {
+	ctx := context.Background()
+	svc, repos := newPreviewFixture(t, 30*24*time.Hour)
+	owner := uuid.New()
+	form := repos.createForm(t, &models.Form{UserID: owner, Title: "Survey"})
+
+	if _, err := svc.IssueToken(ctx, form.ID, uuid.New(), IssuePreviewTokenRequest{}); !errors.Is(err, ErrNotFormOwner) {
+		t.Errorf("issued by a stranger: err = %v, want ErrNotFormOwner", err)
+	}
+	if _, err := svc.IssueToken(ctx, form.ID, owner, IssuePreviewTokenRequest{ExpiresIn: 31 * 24 * 3600}); !errors.Is(err, ErrInvalidPreviewExpiry) {
+		t.Errorf("expiry past the maximum: err = %v, want ErrInvalidPreviewExpiry", err)
+	}
+
+	issued, err := svc.IssueToken(ctx, form.ID, owner, IssuePreviewTokenRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := repos.clock.now().Add(7 * 24 * time.Hour); !issued.ExpiresAt.Equal(want) {
+		t.Errorf("expires at %s, want the 7 day default %s", issued.ExpiresAt, want)
+	}
+	if !strings.HasSuffix(issued.PreviewURL, issued.Token) {
+		t.Errorf("preview URL %q does not carry the token", issued.PreviewURL)
+	}
+
+	repos.clock.advance(time.Hour)
+	got, err := svc.GetPreview(ctx, issued.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Preview || !got.ReadOnly || got.Form.ID != form.ID || got.UpdatedSinceIssued {
+		t.Errorf("preview = %+v, want the read-only preview of the form", got)
+	}
+
+	// The preview is of the current draft
+	form.Title = "Survey 2"
+	repos.forms.Update(ctx, form)
+	if got, err := svc.GetPreview(ctx, issued.Token); err != nil || got.Form.Title != "Survey 2" || !got.UpdatedSinceIssued {
+		t.Errorf("preview of the updated form = %+v, %v; want the update", got, err)
+	}
+
+	if _, err := svc.RevokeToken(ctx, form.ID, owner, issued.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.GetPreview(ctx, issued.Token); !errors.Is(err, ErrPreviewTokenInvalid) {
+		t.Errorf("revoked token: err = %v, want ErrPreviewTokenInvalid", err)
+	}
+	if tokens, err := svc.ListTokens(ctx, form.ID, owner); err != nil || len(tokens) != 1 || tokens[0].RevokedAt == nil {
+		t.Errorf("tokens = %+v, %v; want the revoked token", tokens, err)
+	}
+
+	short, err := svc.IssueToken(ctx, form.ID, owner, IssuePreviewTokenRequest{ExpiresIn: 3600})
+	if err != nil {
+		t.Fatal(err)
+	}
+	repos.clock.advance(time.Hour)
+	if _, err := svc.GetPreview(ctx, short.Token); !errors.Is(err, ErrPreviewTokenInvalid) {
+		t.Errorf("expired token: err = %v, want ErrPreviewTokenInvalid", err)
+	}
+}
+
+func TestPreviewTokensOfDeletedForms(t *testing.T) {
+	ctx := context.Background()
+	svc, repos := newPreviewFixture(t, 24*time.Hour)
+	owner := uuid.New()
+	form := repos.createForm(t, &models.Form{UserID: owner, Title: "Survey"})
+
+	// The default expiry is capped to the maximum
+	issued, err := svc.IssueToken(ctx, form.ID, owner, IssuePreviewTokenRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := svc.now().Add(24 * time.Hour); !issued.ExpiresAt.Equal(want) {
+		t.Errorf("expires at %s, want the maximum %s", issued.ExpiresAt, want)
+	}
+
+	repos.forms.Delete(ctx, form.ID)
+	if _, err := svc.IssueToken(ctx, form.ID, owner, IssuePreviewTokenRequest{}); !errors.Is(err, ErrFormNotFound) {
+		t.Errorf("issued for a deleted form: err = %v, want ErrFormNotFound", err)
+	}
+	if _, err := svc.GetPreview(ctx, issued.Token); !errors.Is(err, ErrFormNotFound) {
+		t.Errorf("preview of a deleted form: err = %v, want ErrFormNotFound", err)
+	}
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+	"github.com/Mir00r/X-Form-Backend/shared/storage"
+)
+
+// PrivacyService erases and exports the data of a user for data subject
+// requests. The privacy worker of the event bus calls it when a request is
+// published, and reports its result to the gateway.
+type PrivacyService interface {
+	// Erase removes the data of the user, returning the rows removed per
+	// kind. Erasing a user again removes nothing.
+	Erase(ctx context.Context, req PrivacyRequest) (*PrivacyResult, error)
+	// Export stores an archive of the data of the user, together with the
+	// submissions passed in, and returns a link to download it
+	Export(ctx context.Context, req PrivacyExportRequest) (*PrivacyResult, error)
+	// ExpireExports deletes the archives whose links expired, returning
+	// how many it deleted
+	ExpireExports(ctx context.Context) (int, error)
+	// RunExportExpiry expires archives every interval until ctx is cancelled
+	RunExportExpiry(ctx context.Context, interval time.Duration)
+}
+
+// privacyExportPrefix is where the archives of users are stored
+const privacyExportPrefix = "privacy/exports/"
+
+// PrivacyRequest names the user of a data subject request
+type PrivacyRequest struct {
+	// RequestID is the ID of the request at the gateway
+	RequestID string `json:"request_id" binding:"required,uuid"`
+	UserID    string `json:"user_id" binding:"required,uuid"`
+	Email     string `json:"email"`
+}
+
+// PrivacyExportRequest asks for the archive of a user
+type PrivacyExportRequest struct {
+	PrivacyRequest
+	// Submissions are the responses of the user held by the response store,
+	// archived as they are
+	Submissions []json.RawMessage `json:"submissions"`
+}
+
+// PrivacyResult is what a data subject request did: counts of the records
+// removed or exported, never their content
+type PrivacyResult struct {
+	RequestID   string           `json:"request_id"`
+	Counts      map[string]int64 `json:"counts"`
+	DownloadURL string           `json:"download_url,omitempty"`
+	ExpiresAt   *time.Time       `json:"expires_at,omitempty"`
+}
+
+// privacyArchive is the JSON document a user's export downloads
+type privacyArchive struct {
+	RequestID      string                  `json:"request_id"`
+	UserID         string                  `json:"user_id"`
+	GeneratedAt    time.Time               `json:"generated_at"`
+	Forms          []archivedForm          `json:"forms"`
+	Collaborations []*models.Collaborator  `json:"collaborations"`
+	Drafts         []*models.ResponseDraft `json:"drafts"`
+	Submissions    []json.RawMessage       `json:"submissions"`
+}
+
+type archivedForm struct {
+	*models.Form
+	Questions []*models.Question `json:"questions"`
+}
+
+// privacyService implements PrivacyService interface
+type privacyService struct {
+	repo      repository.PrivacyRepository
+	drafts    repository.DraftCache
+	storage   storage.Backend
+	policy    models.ErasurePolicy
+	exportTTL time.Duration
+	now       func() time.Time
+}
+
+// NewPrivacyService creates a new privacy service instance. Owned forms are
+// erased as policy says, and export links stay valid for exportTTL.
+func NewPrivacyService(repo repository.PrivacyRepository, drafts repository.DraftCache, store storage.Backend, policy models.ErasurePolicy, exportTTL time.Duration) PrivacyService {
+	return &privacyService{
+		repo:      repo,
+		drafts:    drafts,
+		storage:   store,
+		policy:    policy,
+		exportTTL: exportTTL,
+		now:       time.Now,
+	}
+}
+
+// Erase removes the rows in one transaction, then the cached drafts and the
+// stored files of the deleted rows. Files failing to delete are logged
+// rather than failing the erasure, whose rows are gone already.
+func (s *privacyService) Erase(ctx context.Context, req PrivacyRequest) (*PrivacyResult, error) {
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID: %w", err)
+	}
+
+	erasure, err := s.repo.EraseUser(ctx, userID, req.Email, s.policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to erase user data: %w", err)
+	}
+
+	for _, draft := range erasure.Drafts {
+		if _, err := s.drafts.Take(ctx, draft.FormID, draft.OwnerKey); err != nil && !errors.Is(err, repository.ErrDraftNotFound) {
+			return nil, fmt.Errorf("failed to remove cached draft: %w", err)
+		}
+	}
+	for _, key := range erasure.ObjectKeys {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			log.Printf("privacy request %s: failed to delete object %s: %v", req.RequestID, key, err)
+		}
+	}
+
+	return &PrivacyResult{RequestID: req.RequestID, Counts: erasure.Counts}, nil
+}
+
+// Export writes the archive to privacy/exports/{request}.json, so exporting
+// the same request again replaces it
+func (s *privacyService) Export(ctx context.Context, req PrivacyExportRequest) (*PrivacyResult, error) {
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID: %w", err)
+	}
+
+	data, err := s.repo.ExportUser(ctx, userID, req.Email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read user data: %w", err)
+	}
+
+	archive := privacyArchive{
+		RequestID:      req.RequestID,
+		UserID:         req.UserID,
+		GeneratedAt:    s.now().UTC(),
+		Forms:          make([]archivedForm, 0, len(data.Forms)),
+		Collaborations: data.Collaborations,
+		Drafts:         data.Drafts,
+		Submissions:    req.Submissions,
+	}
+	byForm := make(map[uuid.UUID]*archivedForm, len(data.Forms))
+	for _, form := range data.Forms {
+		archive.Forms = append(archive.Forms, archivedForm{Form: form, Questions: []*models.Question{}})
+	}
+	for i := range archive.Forms {
+		byForm[archive.Forms[i].ID] = &archive.Forms[i]
+	}
+	for _, question := range data.Questions {
+		if form, ok := byForm[question.FormID]; ok {
+			form.Questions = append(form.Questions, question)
+		}
+	}
+	if archive.Collaborations == nil {
+		archive.Collaborations = []*models.Collaborator{}
+	}
+	if archive.Drafts == nil {
+		archive.Drafts = []*models.ResponseDraft{}
+	}
+	if archive.Submissions == nil {
+		archive.Submissions = []json.RawMessage{}
+	}
+
+	body, err := json.MarshalIndent(archive, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode export: %w", err)
+	}
+	key := fmt.Sprintf("%s%s.json", privacyExportPrefix, req.RequestID)
+	if err := s.storage.Put(ctx, key, "application/json", body); err != nil {
+		return nil, fmt.Errorf("failed to store export: %w", err)
+	}
+	url, err := s.storage.PresignGet(ctx, key, s.exportTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign export link: %w", err)
+	}
+	expiresAt := archive.GeneratedAt.Add(s.exportTTL)
+
+	return &PrivacyResult{
+		RequestID: req.RequestID,
+		Counts: map[string]int64{
+			"forms":          int64(len(data.Forms)),
+			"questions":      int64(len(data.Questions)),
+			"collaborations": int64(len(data.Collaborations)),
+			"drafts":         int64(len(data.Drafts)),
+		},
+		DownloadURL: url,
+		ExpiresAt:   &expiresAt,
+	}, nil
+}
+
+// ExpireExports deletes the archives stored before their links expired. The
+// archives hold personal data, so none outlives its link.
+func (s *privacyService) ExpireExports(ctx context.Context) (int, error) {
+	return storage.Expire(ctx, s.storage, privacyExportPrefix, s.now().Add(-s.exportTTL))
+}
+
+// RunExportExpiry runs ExpireExports on a ticker until ctx is cancelled
+func (s *privacyService) RunExportExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := s.ExpireExports(ctx)
+			if err != nil {
+				log.Printf("Privacy export expiry failed: %v", err)
+				continue
+			}
+			if expired > 0 {
+				log.Printf("Deleted %d expired privacy exports", expired)
+			}
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+	"github.com/Mir00r/X-Form-Backend/shared/storage"
+)
+
+// stubPrivacyRepository returns a fixed erasure and export
+type stubPrivacyRepository struct {
+	erasure *repository.Erasure
+	data    *repository.UserData
+	policy  models.ErasurePolicy
+}
+
+func (r *stubPrivacyRepository) EraseUser(_ context.Context, _ uuid.UUID, _ string, policy models.ErasurePolicy) (*repository.Erasure, error) {
+	r.policy = policy
+	return r.erasure, nil
+}
+
+func (r *stubPrivacyRepository) ExportUser(_ context.Context, _ uuid.UUID, _ string) (*repository.UserData, error) {
+	return r.data, nil
+}
+
+func TestEraseRemovesCachedDraftsAndFiles(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewLocalStorage(t.TempDir(), "http://localhost:8001", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(ctx, "uploads/a", "text/plain", []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	drafts := newMemoryDrafts(time.Now)
+	draft := &models.ResponseDraft{FormID: uuid.New(), OwnerKey: "user:1", ExpiresAt: time.Now().Add(time.Hour)}
+	kept := &models.ResponseDraft{FormID: uuid.New(), OwnerKey: "user:2", ExpiresAt: time.Now().Add(time.Hour)}
+	drafts.store(draft)
+	drafts.store(kept)
+
+	repo := &stubPrivacyRepository{erasure: &repository.Erasure{
+		Counts:     map[string]int64{"forms_deleted": 1, "drafts_removed": 1},
+		ObjectKeys: []string{"uploads/a", "uploads/missing"},
+		Drafts:     []*models.ResponseDraft{draft},
+	}}
+	svc := NewPrivacyService(repo, memoryDraftCache{drafts}, store, models.ErasurePolicyAnonymize, time.Hour)
+
+	result, err := svc.Erase(ctx, PrivacyRequest{RequestID: uuid.NewString(), UserID: uuid.NewString()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Counts["forms_deleted"] != 1 || repo.policy != models.ErasurePolicyAnonymize {
+		t.Errorf("counts = %v, policy = %s", result.Counts, repo.policy)
+	}
+	if drafts.count() != 1 {
+		t.Errorf("%d cached drafts left, want only the draft of another user", drafts.count())
+	}
+	if _, err := store.Stat(ctx, "uploads/a"); err == nil {
+		t.Error("the file of the deleted form was kept")
+	}
+}
+
+func TestExportArchivesUserData(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := storage.NewLocalStorage(dir, "http://localhost:8001", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	form := &models.Form{ID: uuid.New(), Title: "Feedback"}
+	repo := &stubPrivacyRepository{data: &repository.UserData{
+		Forms: []*models.Form{form},
+		Questions: []*models.Question{
+			{ID: uuid.New(), FormID: form.ID, Title: "Why?"},
+			{ID: uuid.New(), FormID: form.ID, Title: "Why not?"},
+		},
+	}}
+	svc := NewPrivacyService(repo, nil, store, models.ErasurePolicyDelete, 24*time.Hour)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	svc.(*privacyService).now = func() time.Time { return now }
+
+	requestID := uuid.NewString()
+	result, err := svc.Export(ctx, PrivacyExportRequest{
+		PrivacyRequest: PrivacyRequest{RequestID: requestID, UserID: uuid.NewString()},
+		Submissions:    []json.RawMessage{json.RawMessage(`{"response_id":"r1"}`)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.DownloadURL == "" || !result.ExpiresAt.Equal(now.Add(24*time.Hour)) {
+		t.Errorf("download link = %q until %v", result.DownloadURL, result.ExpiresAt)
+	}
+	if result.Counts["forms"] != 1 || result.Counts["questions"] != 2 {
+		t.Errorf("counts = %v", result.Counts)
+	}
+
+	reader, err := store.Open(ctx, "privacy/exports/"+requestID+".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var archive struct {
+		Forms []struct {
+			ID        uuid.UUID         `json:"id"`
+			Questions []json.RawMessage `json:"questions"`
+		} `json:"forms"`
+		Collaborations []json.RawMessage `json:"collaborations"`
+		Submissions    []json.RawMessage `json:"submissions"`
+	}
+	if err := json.Unmarshal(body, &archive); err != nil {
+		t.Fatal(err)
+	}
+	if len(archive.Forms) != 1 || len(archive.Forms[0].Questions) != 2 || len(archive.Submissions) != 1 {
+		t.Errorf("archive = %s", body)
+	}
+	if archive.Collaborations == nil {
+		t.Error("archive lists no collaborations as null rather than []")
+	}
+}
+
+func TestExpireExportsDeletesArchivesWithExpiredLinks(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewLocalStorage(t.TempDir(), "http://localhost:8001", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"privacy/exports/a.json", "privacy/exports/b.json", "uploads/kept"} {
+		if err := store.Put(ctx, key, "application/json", []byte("{}")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	svc := NewPrivacyService(&stubPrivacyRepository{}, nil, store, models.ErasurePolicyDelete, 24*time.Hour)
+	svc.(*privacyService).now = func() time.Time { return time.Now().Add(24*time.Hour + time.Minute) }
+	if expired, err := svc.ExpireExports(ctx); err != nil || expired != 2 {
+		t.Fatalf("expired %d exports: %v", expired, err)
+	}
+	if _, err := store.Stat(ctx, "uploads/kept"); err != nil {
+		t.Errorf("an object outside the exports was deleted: %v", err)
+	}
+
+	if err := store.Put(ctx, "privacy/exports/c.json", "application/json", []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+	svc.(*privacyService).now = time.Now
+	if expired, err := svc.ExpireExports(ctx); err != nil || expired != 0 {
+		t.Fatalf("expired %d exports whose links are valid: %v", expired, err)
+	}
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/access"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+)
+
+var (
+	// ErrQuestionNotFound is returned for questions that are not questions
+	// of the form
+	ErrQuestionNotFound = errors.New("question not found")
+	// ErrInvalidQuestionKey is returned for question keys that are not well
+	// formed
+	ErrInvalidQuestionKey = errors.New("invalid question key")
+	// ErrQuestionKeyTaken is returned when another question of the form has
+	// the key, or was renamed from it
+	ErrQuestionKeyTaken = errors.New("question key is already taken in this form")
+)
+
+// RenameQuestionKeyRequest renames the key of a question. The former key
+// becomes an alias of the question, so the responses projected under it keep
+// being aggregated with the question.
+type RenameQuestionKeyRequest struct {
+	Key string `json:"key" binding:"required,max=64" example:"age_group"`
+}
+
+// RenameQuestionKey renames the key of a question of a form. A question may
+// be renamed back to one of its former keys, never to the key, current or
+// former, of another question of the form.
+func (s *formService) RenameQuestionKey(ctx context.Context, formID, questionID, userID uuid.UUID, req RenameQuestionKeyRequest) (*models.Question, error) {
+	form, err := s.guard.authorize(ctx, formID, userID, access.Edit)
+	if err != nil {
+		return nil, err
+	}
+	question, err := s.questionRepo.GetByID(ctx, questionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || err == nil && question.FormID != form.ID {
+		return nil, ErrQuestionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get question: %w", err)
+	}
+
+	key := models.NormalizeQuestionKey(req.Key)
+	if err := models.ValidateQuestionKey(key); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidQuestionKey, err)
+	}
+	if key == question.Key {
+		return question, nil
+	}
+	taken, err := takenQuestionKeys(ctx, s.questionRepo, form.ID, question.ID)
+	if err != nil {
+		return nil, err
+	}
+	if taken[key] {
+		return nil, ErrQuestionKeyTaken
+	}
+
+	alias := &models.QuestionKeyAlias{
+		FormID:     form.ID,
+		Key:        question.Key,
+		QuestionID: question.ID,
+		RenamedBy:  userID,
+	}
+	question.Key = key
+	err = s.activity.record(ctx, questionActivity(question, userID, models.ActivityQuestionUpdated, []string{"key"}), func(ctx context.Context) error {
+		return s.questionRepo.RenameKey(ctx, question, alias)
+	})
+	if errors.Is(err, repository.ErrQuestionKeyTaken) {
+		return nil, ErrQuestionKeyTaken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename question key: %w", err)
+	}
+	s.publishFormChanged(ctx, events.FormUpdated, form, slugOf(form))
+
+	return question, nil
+}
+
+// takenQuestionKeys returns the keys of the questions of a form besides
+// except, along with the keys they were renamed from
+func takenQuestionKeys(ctx context.Context, questionRepo repository.QuestionRepository, formID, except uuid.UUID) (map[string]bool, error) {
+	questions, err := questionRepo.GetByFormID(ctx, formID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get questions: %w", err)
+	}
+	aliases, err := questionRepo.GetKeyAliases(ctx, formID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get question key aliases: %w", err)
+	}
+	return keysTaken(questions, aliases, except), nil
+}
+
+// keysTaken returns the keys of questions besides except, along with the
+// keys they were renamed from. The former keys of deleted questions are
+// free, so that a question recreated with one is counted with the answers
+// given under it.
+func keysTaken(questions []*models.Question, aliases []*models.QuestionKeyAlias, except uuid.UUID) map[string]bool {
+	taken := make(map[string]bool, len(questions)+len(aliases))
+	live := make(map[uuid.UUID]bool, len(questions))
+	for _, question := range questions {
+		live[question.ID] = true
+		if question.ID != except && question.Key != "" {
+			taken[question.Key] = true
+		}
+	}
+	for _, alias := range aliases {
+		if live[alias.QuestionID] && alias.QuestionID != except {
+			taken[alias.Key] = true
+		}
+	}
+	return taken
+}
+
+// assignQuestionKeys checks the keys given to new questions are well formed
+// and not taken, and generates the missing ones from the titles of the
+// questions. The keys assigned are added to taken.
+func assignQuestionKeys(questions []*models.Question, taken map[string]bool) error {
+	for _, question := range questions {
+		if question.Key == "" {
+			continue
+		}
+		question.Key = models.NormalizeQuestionKey(question.Key)
+		if err := models.ValidateQuestionKey(question.Key); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidQuestionKey, err)
+		}
+		if taken[question.Key] {
+			return fmt.Errorf("%w: %s", ErrQuestionKeyTaken, question.Key)
+		}
+		taken[question.Key] = true
+	}
+	for _, question := range questions {
+		if question.Key == "" {
+			question.Key = models.UniqueQuestionKey(models.QuestionKeyFromText(question.Title), taken)
+		}
+	}
+	return nil
+}
+
+// questionKeys returns the keys the answers to each question of a form are
+// projected under, by question ID: its current key, then those it was
+// renamed from. Analytics and exports join the projection on them, so that
+// the answers given before a rename, or to a deleted question whose key was
+// taken by a new one, are counted with the question.
+func questionKeys(ctx context.Context, questionRepo repository.QuestionRepository, questions []*models.Question) (map[uuid.UUID][]string, error) {
+	keys := make(map[uuid.UUID][]string, len(questions))
+	if len(questions) == 0 {
+		return keys, nil
+	}
+	for _, question := range questions {
+		if question.Key != "" {
+			keys[question.ID] = []string{question.Key}
+		}
+	}
+	aliases, err := questionRepo.GetKeyAliases(ctx, questions[0].FormID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get question key aliases: %w", err)
+	}
+	for _, alias := range aliases {
+		if _, ok := keys[alias.QuestionID]; ok {
+			keys[alias.QuestionID] = append(keys[alias.QuestionID], alias.Key)
+		}
+	}
+	return keys, nil
+}
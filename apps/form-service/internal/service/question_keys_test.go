@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+)
+
+func TestQuestionKeyFromText(t *testing.T) {
+	for text, want := range map[string]string{
+		"Age group":                  "age_group",
+		"  Where do you live?  ":     "where_do_you_live",
+		"Café & crème":               "cafe_creme",
+		"2024 budget":                "q_2024_budget",
+		"???":                        "question",
+		"Rate our service (1 to 5)!": "rate_our_service_1_to_5",
+	} {
+		if got := models.QuestionKeyFromText(text); got != want {
+			t.Errorf("QuestionKeyFromText(%q) = %q, want %q", text, got, want)
+		}
+		if err := models.ValidateQuestionKey(models.QuestionKeyFromText(text)); err != nil {
+			t.Errorf("key of %q: %v", text, err)
+		}
+	}
+
+	taken := map[string]bool{"age": true, "age_2": true}
+	if got := models.UniqueQuestionKey("age", taken); got != "age_3" || !taken["age_3"] {
+		t.Errorf("UniqueQuestionKey = %q, want age_3 taken", got)
+	}
+}
+
+func TestRenameQuestionKey(t *testing.T) {
+	ctx := context.Background()
+	repos := newMemoryStore(time.Now())
+	owner := uuid.New()
+	form := repos.createForm(t, &models.Form{UserID: owner, Title: "Survey"})
+	svc := repos.formService()
+
+	add := func(title, key string) (*models.Question, error) {
+		return svc.AddQuestion(ctx, form.ID, owner, AddQuestionRequest{Type: models.QuestionTypeText, Title: title, Key: key})
+	}
+	age, err := add("Age group", "")
+	if err != nil || age.Key != "age_group" {
+		t.Fatalf("AddQuestion = %+v, %v; want the key generated from the title", age, err)
+	}
+	again, err := add("Age group?", "")
+	if err != nil || again.Key != "age_group_2" {
+		t.Fatalf("AddQuestion = %+v, %v; want a unique key", again, err)
+	}
+	if _, err := add("Age", " AGE_GROUP "); !errors.Is(err, ErrQuestionKeyTaken) {
+		t.Errorf("taken key: err = %v, want ErrQuestionKeyTaken", err)
+	}
+	if _, err := add("Age", "1st"); !errors.Is(err, ErrInvalidQuestionKey) {
+		t.Errorf("malformed key: err = %v, want ErrInvalidQuestionKey", err)
+	}
+
+	rename := func(question *models.Question, key string) (*models.Question, error) {
+		return svc.RenameQuestionKey(ctx, form.ID, question.ID, owner, RenameQuestionKeyRequest{Key: key})
+	}
+	if _, err := rename(age, "age_group_2"); !errors.Is(err, ErrQuestionKeyTaken) {
+		t.Errorf("rename to the key of another question: err = %v, want ErrQuestionKeyTaken", err)
+	}
+	if _, err := rename(age, "Age Group"); !errors.Is(err, ErrInvalidQuestionKey) {
+		t.Errorf("rename to a malformed key: err = %v, want ErrInvalidQuestionKey", err)
+	}
+	renamed, err := rename(age, "age_band")
+	if err != nil || renamed.Key != "age_band" {
+		t.Fatalf("RenameQuestionKey = %+v, %v; want age_band", renamed, err)
+	}
+	if len(repos.questions.aliases) != 1 || repos.questions.aliases[0].Key != "age_group" || repos.questions.aliases[0].QuestionID != age.ID {
+		t.Errorf("aliases = %+v, want age_group kept for the question", repos.questions.aliases)
+	}
+	if _, err := rename(again, "age_group"); !errors.Is(err, ErrQuestionKeyTaken) {
+		t.Errorf("rename to the former key of another question: err = %v, want ErrQuestionKeyTaken", err)
+	}
+	if _, err := add("Age", "age_group"); !errors.Is(err, ErrQuestionKeyTaken) {
+		t.Errorf("new question with a former key: err = %v, want ErrQuestionKeyTaken", err)
+	}
+
+	keys, err := questionKeys(ctx, repos.questions, []*models.Question{renamed})
+	if err != nil || len(keys[age.ID]) != 2 || keys[age.ID][0] != "age_band" || keys[age.ID][1] != "age_group" {
+		t.Errorf("questionKeys = %v, %v; want the current key, then the former", keys, err)
+	}
+
+	if renamed, err = rename(age, "age_group"); err != nil || renamed.Key != "age_group" {
+		t.Fatalf("rename back = %+v, %v; want the former key restored", renamed, err)
+	}
+	if len(repos.questions.aliases) != 1 || repos.questions.aliases[0].Key != "age_band" {
+		t.Errorf("aliases = %+v, want only age_band left", repos.questions.aliases)
+	}
+
+	// The keys of deleted questions are free again
+	if err := repos.questions.Delete(ctx, age.ID); err != nil {
+		t.Fatal(err)
+	}
+	if recreated, err := add("Age", "age_band"); err != nil || recreated.Key != "age_band" {
+		t.Errorf("AddQuestion = %+v, %v; want the key of the deleted question", recreated, err)
+	}
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/access"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/receipt"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+	"github.com/Mir00r/X-Form-Backend/shared/storage"
+)
+
+// ErrReceiptInvalid is returned for receipt requests missing the response
+var ErrReceiptInvalid = errors.New("invalid receipt request")
+
+// ReceiptService defines the interface for the PDF receipts of responses.
+// The response service, which holds the responses and checks who may read
+// them, asks for the receipt of a response with its answers; receipts are
+// rendered once per revision and locale, then served from storage.
+type ReceiptService interface {
+	Receipt(ctx context.Context, formID uuid.UUID, req ReceiptRequest) (*RenderedReceipt, error)
+}
+
+// ReceiptRequest is a response to render the receipt of
+type ReceiptRequest struct {
+	ResponseID  string    `json:"response_id" binding:"required" example:"resp_1718031234_ab12cd"`
+	Revision    int       `json:"revision" example:"1"`
+	SubmittedAt time.Time `json:"submitted_at" binding:"required"`
+	// Answers are keyed by question ID
+	Answers map[string]json.RawMessage `json:"answers" swaggertype:"object"`
+	// Locale picks the translation of the titles, the default locale of
+	// the form otherwise
+	Locale string `json:"locale,omitempty" example:"he"`
+	// UserID is set when the receipt is asked for by a user other than the
+	// respondent, who must be allowed to view the form
+	UserID *uuid.UUID `json:"user_id,omitempty"`
+}
+
+// RenderedReceipt is the PDF receipt of a response
+type RenderedReceipt struct {
+	PDF []byte
+	// Hash is the verification hash printed on the receipt
+	Hash string
+	// Cached is set when the receipt was served from storage
+	Cached bool
+}
+
+// receiptService implements ReceiptService interface
+type receiptService struct {
+	formRepo     repository.FormRepository
+	questionRepo repository.QuestionRepository
+	uploads      repository.FileUploadRepository
+	storage      storage.Backend
+	renderer     *receipt.Renderer
+	guard        formGuard
+}
+
+// NewReceiptService creates a new receipt service instance rendering with
+// renderer and caching receipts in store
+func NewReceiptService(formRepo repository.FormRepository, questionRepo repository.QuestionRepository, collaboratorRepo repository.CollaboratorRepository, orgRepo repository.OrganizationRepository, uploads repository.FileUploadRepository, store storage.Backend, renderer *receipt.Renderer) ReceiptService {
+	return &receiptService{
+		formRepo:     formRepo,
+		questionRepo: questionRepo,
+		uploads:      uploads,
+		storage:      store,
+		renderer:     renderer,
+		guard:        formGuard{forms: formRepo, collaborators: collaboratorRepo, orgs: organizationScope{orgs: orgRepo}},
+	}
+}
+
+// Receipt returns the receipt of a response, rendering and storing it the
+// first time it is asked for at its revision
+func (s *receiptService) Receipt(ctx context.Context, formID uuid.UUID, req ReceiptRequest) (*RenderedReceipt, error) {
+	if req.ResponseID == "" || req.SubmittedAt.IsZero() {
+		return nil, fmt.Errorf("%w: the response ID and submission time are required", ErrReceiptInvalid)
+	}
+	if req.Revision < 1 {
+		req.Revision = 1
+	}
+
+	var form *models.Form
+	var err error
+	if req.UserID != nil {
+		form, err = s.guard.authorize(ctx, formID, *req.UserID, access.View)
+	} else {
+		form, err = s.formRepo.GetByID(ctx, formID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrFormNotFound
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	translations, err := form.GetTranslations()
+	if err != nil {
+		return nil, err
+	}
+	bundle, locale := models.ResolveLocale(translations, req.Locale, form.GetDefaultLocale())
+
+	content, err := s.content(ctx, form, req, bundle)
+	if err != nil {
+		return nil, err
+	}
+	hash := content.Hash()
+
+	key := receiptKey(formID, req.ResponseID, req.Revision, locale)
+	if pdf, err := s.cached(ctx, key); err == nil {
+		return &RenderedReceipt{PDF: pdf, Hash: hash, Cached: true}, nil
+	} else if !errors.Is(err, storage.ErrObjectNotFound) {
+		log.Printf("Failed to read cached receipt %s: %v", key, err)
+	}
+
+	pdf, err := s.renderer.Render(content)
+	if err != nil {
+		return nil, err
+	}
+	// A receipt that fails to be stored is rendered again next time
+	if err := s.storage.Put(ctx, key, receipt.ContentType, pdf); err != nil {
+		log.Printf("Failed to cache receipt %s: %v", key, err)
+	}
+	return &RenderedReceipt{PDF: pdf, Hash: hash}, nil
+}
+
+// content assembles the receipt of a response: every question of the form
+// in order, titled in the locale of bundle, with its answer or the names of
+// the files uploaded to it
+func (s *receiptService) content(ctx context.Context, form *models.Form, req ReceiptRequest, bundle models.TranslationBundle) (receipt.Receipt, error) {
+	questions, err := s.questionRepo.GetByFormID(ctx, form.ID)
+	if err != nil {
+		return receipt.Receipt{}, fmt.Errorf("failed to get questions: %w", err)
+	}
+	sort.SliceStable(questions, func(i, j int) bool { return questions[i].Order < questions[j].Order })
+	files := make(map[uuid.UUID][]string)
+	if s.uploads != nil {
+		attached, err := s.uploads.ListAttached(ctx, form.ID, []string{req.ResponseID})
+		if err != nil {
+			return receipt.Receipt{}, fmt.Errorf("failed to get attached files: %w", err)
+		}
+		for _, upload := range attached {
+			files[upload.QuestionID] = append(files[upload.QuestionID], upload.FileName)
+		}
+	}
+
+	title := form.Title
+	if bundle.Title != "" {
+		title = bundle.Title
+	}
+	content := receipt.Receipt{
+		FormID:      form.ID.String(),
+		FormTitle:   title,
+		ResponseID:  req.ResponseID,
+		Revision:    req.Revision,
+		SubmittedAt: req.SubmittedAt,
+		Answers:     make([]receipt.Answer, 0, len(questions)),
+	}
+	for _, question := range questions {
+		answer := receipt.Answer{QuestionID: question.ID.String(), Question: question.Title}
+		if translated := bundle.Questions[answer.QuestionID].Title; translated != "" {
+			answer.Question = translated
+		}
+		if question.Type == models.QuestionTypeFile {
+			answer.Files = files[question.ID]
+		} else if raw, ok := req.Answers[answer.QuestionID]; ok {
+			answer.Text = exportCell(raw)
+		}
+		content.Answers = append(content.Answers, answer)
+	}
+	return content, nil
+}
+
+// cached reads a stored receipt
+func (s *receiptService) cached(ctx context.Context, key string) ([]byte, error) {
+	if _, err := s.storage.Stat(ctx, key); err != nil {
+		return nil, err
+	}
+	r, err := s.storage.Open(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// receiptKey is the storage key of the receipt of a response at a revision
+// in a locale
+func receiptKey(formID uuid.UUID, responseID string, revision int, locale string) string {
+	name := "r" + strconv.Itoa(revision)
+	if locale != "" {
+		name += "-" + url.PathEscape(locale)
+	}
+	return fmt.Sprintf("receipts/%s/%s/%s.pdf", formID, url.PathEscape(responseID), name)
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/receipt"
+)
+
+// newReceiptFixture creates a receipt service over the form of an export
+// fixture, asking for a name and a CV
+func newReceiptFixture(t *testing.T) (*receiptService, *exportFixture) {
+	t.Helper()
+	f := newExportFixture(t, 0)
+	fonts, err := receipt.LoadFonts("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := NewReceiptService(f.forms, f.questions, nil, f.orgs, f.uploads, f.store, receipt.NewRenderer(fonts)).(*receiptService)
+	return svc, f
+}
+
+func receiptRequest(f *exportFixture, name string) ReceiptRequest {
+	answer, _ := json.Marshal(name)
+	return ReceiptRequest{
+		ResponseID:  "resp-001",
+		Revision:    1,
+		SubmittedAt: time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC),
+		Answers:     map[string]json.RawMessage{f.name.ID.String(): answer},
+	}
+}
+
+func TestReceiptContent(t *testing.T) {
+	svc, f := newReceiptFixture(t)
+	f.attach(t, "resp-001", "cv.pdf", []byte("%PDF"))
+	f.attach(t, "resp-002", "other.pdf", []byte("%PDF"))
+	if err := f.form.SetTranslation("fr", models.TranslationBundle{
+		Title:     "Candidatures",
+		Questions: map[string]models.QuestionTranslation{f.name.ID.String(): {Title: "Nom"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	translations, _ := f.form.GetTranslations()
+	bundle, _ := models.ResolveLocale(translations, "fr", f.form.GetDefaultLocale())
+	content, err := svc.content(context.Background(), f.form, receiptRequest(f, "Ada"), bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content.FormTitle != "Candidatures" {
+		t.Errorf("title = %q, want the translation", content.FormTitle)
+	}
+	// Questions in order, the file question answered by the names of the
+	// files of the response only
+	if len(content.Answers) != 2 {
+		t.Fatalf("%d answers, want both questions", len(content.Answers))
+	}
+	if name := content.Answers[0]; name.Question != "Nom" || name.Text != "Ada" {
+		t.Errorf("first answer = %q: %q, want the translated name question", name.Question, name.Text)
+	}
+	if cv := content.Answers[1]; cv.Question != "CV" || len(cv.Files) != 1 || cv.Files[0] != "cv.pdf" {
+		t.Errorf("second answer = %q: %v, want the CV of the response", cv.Question, cv.Files)
+	}
+}
+
+func TestReceiptIsCachedPerRevision(t *testing.T) {
+	ctx := context.Background()
+	svc, f := newReceiptFixture(t)
+	req := receiptRequest(f, "Ada")
+
+	first, err := svc.Receipt(ctx, f.form.ID, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Cached || !bytes.HasPrefix(first.PDF, []byte("%PDF-")) {
+		t.Fatalf("first receipt cached = %v, want a rendered PDF", first.Cached)
+	}
+	if _, err := f.store.Stat(ctx, receiptKey(f.form.ID, req.ResponseID, 1, "en")); err != nil {
+		t.Errorf("receipt not stored: %v", err)
+	}
+
+	// The same revision is served from storage, even once answered
+	// differently, as revisions are never changed
+	again, err := svc.Receipt(ctx, f.form.ID, receiptRequest(f, "Ada"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !again.Cached || !bytes.Equal(again.PDF, first.PDF) || again.Hash != first.Hash {
+		t.Errorf("second receipt cached = %v, want the stored receipt", again.Cached)
+	}
+
+	edited := receiptRequest(f, "Ada King")
+	edited.Revision = 2
+	second, err := svc.Receipt(ctx, f.form.ID, edited)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Cached || second.Hash == first.Hash {
+		t.Errorf("edited receipt cached = %v, want the new revision rendered", second.Cached)
+	}
+}
+
+func TestReceiptAccess(t *testing.T) {
+	ctx := context.Background()
+	svc, f := newReceiptFixture(t)
+
+	req := receiptRequest(f, "Ada")
+	req.ResponseID = ""
+	if _, err := svc.Receipt(ctx, f.form.ID, req); !errors.Is(err, ErrReceiptInvalid) {
+		t.Errorf("receipt without a response: err = %v, want ErrReceiptInvalid", err)
+	}
+	if _, err := svc.Receipt(ctx, uuid.New(), receiptRequest(f, "Ada")); !errors.Is(err, ErrFormNotFound) {
+		t.Errorf("receipt of another form: err = %v, want ErrFormNotFound", err)
+	}
+
+	stranger := uuid.New()
+	req = receiptRequest(f, "Ada")
+	req.UserID = &stranger
+	if _, err := svc.Receipt(ctx, f.form.ID, req); !errors.Is(err, ErrNotFormOwner) {
+		t.Errorf("receipt for a stranger: err = %v, want ErrNotFormOwner", err)
+	}
+	req.UserID = &f.owner
+	if _, err := svc.Receipt(ctx, f.form.ID, req); err != nil {
+		t.Errorf("receipt for the owner: err = %v", err)
+	}
+}
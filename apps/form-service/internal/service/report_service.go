@@ -0,0 +1,451 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/analytics"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+	"github.com/Mir00r/X-Form-Backend/shared/storage"
+)
+
+const (
+	// reportListLimit bounds the reports listed for a form
+	reportListLimit = 100
+	// reportBatchSize bounds the schedules run per scheduler tick
+	reportBatchSize = 50
+	// reportDownloadTTL is how long the download links of a report listing stay valid
+	reportDownloadTTL = 15 * time.Minute
+	// reportEmailLinkTTL is how long the download link emailed to the
+	// recipients stays valid, the longest S3 allows
+	reportEmailLinkTTL = 7 * 24 * time.Hour
+)
+
+// ErrReportScheduleInvalid is returned for report schedules that can't be enrolled
+var ErrReportScheduleInvalid = errors.New("invalid report schedule")
+
+// ReportService defines the interface for scheduled form reports. A single
+// replica holding the scheduler lock generates the due reports, stores them
+// as downloadable artifacts and has the notification worker email them.
+type ReportService interface {
+	UpsertSchedule(ctx context.Context, formID, userID uuid.UUID, req ReportScheduleRequest) (*models.ReportSchedule, error)
+	GetSchedule(ctx context.Context, formID, userID uuid.UUID) (*models.ReportSchedule, error)
+	DeleteSchedule(ctx context.Context, formID, userID uuid.UUID) error
+	ListReports(ctx context.Context, formID, userID uuid.UUID) ([]*models.Report, error)
+	// RunDueReports generates the reports of the due schedules, returning
+	// the number generated
+	RunDueReports(ctx context.Context) (int, error)
+	RunScheduler(ctx context.Context, interval time.Duration)
+}
+
+// ReportScheduleRequest enrolls a form in scheduled reports
+type ReportScheduleRequest struct {
+	Frequency models.ReportFrequency `json:"frequency" binding:"required" example:"weekly"`
+	// Weekday is the day weekly reports run on, 0 being Sunday
+	Weekday int `json:"weekday" example:"1"`
+	// SendAt is the local time of day reports run at, 09:00 by default
+	SendAt string `json:"send_at" example:"09:00"`
+	// Timezone is an IANA time zone, UTC by default
+	Timezone   string              `json:"timezone" example:"Europe/Berlin"`
+	Recipients []string            `json:"recipients" example:"owner@example.com"`
+	Format     models.ReportFormat `json:"format" example:"html"`
+	// IncludeEmpty also reports periods without responses
+	IncludeEmpty bool `json:"include_empty"`
+}
+
+// reportService implements ReportService interface
+type reportService struct {
+	formRepo   repository.FormRepository
+	reportRepo repository.ReportRepository
+	aggregator analytics.Aggregator
+	storage    storage.Backend
+	publisher  events.Publisher
+	lock       repository.Lock
+	now        func() time.Time
+}
+
+// NewReportService creates a new report service instance. Reports are
+// aggregated by aggregator and stored in store; lock elects the replica
+// running the scheduler.
+func NewReportService(formRepo repository.FormRepository, reportRepo repository.ReportRepository, aggregator analytics.Aggregator, store storage.Backend, publisher events.Publisher, lock repository.Lock) ReportService {
+	return &reportService{
+		formRepo:   formRepo,
+		reportRepo: reportRepo,
+		aggregator: aggregator,
+		storage:    store,
+		publisher:  publisher,
+		lock:       lock,
+		now:        time.Now,
+	}
+}
+
+// UpsertSchedule enrolls the form, or replaces its schedule. The first run
+// is the next one after now.
+func (s *reportService) UpsertSchedule(ctx context.Context, formID, userID uuid.UUID, req ReportScheduleRequest) (*models.ReportSchedule, error) {
+	if _, err := s.getOwnedForm(ctx, formID, userID); err != nil {
+		return nil, err
+	}
+
+	schedule := &models.ReportSchedule{
+		FormID:       formID,
+		Frequency:    req.Frequency,
+		Weekday:      req.Weekday,
+		SendAt:       req.SendAt,
+		Timezone:     req.Timezone,
+		Recipients:   make([]string, 0, len(req.Recipients)),
+		Format:       req.Format,
+		IncludeEmpty: req.IncludeEmpty,
+	}
+	if schedule.SendAt == "" {
+		schedule.SendAt = "09:00"
+	}
+	if schedule.Timezone == "" {
+		schedule.Timezone = "UTC"
+	}
+	if schedule.Format == "" {
+		schedule.Format = models.ReportFormatHTML
+	}
+	for _, recipient := range req.Recipients {
+		schedule.Recipients = append(schedule.Recipients, strings.TrimSpace(recipient))
+	}
+	if err := schedule.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrReportScheduleInvalid, err)
+	}
+
+	now := s.now().UTC()
+	next, err := schedule.NextRun(now)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrReportScheduleInvalid, err)
+	}
+	schedule.NextRunAt = next
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+
+	if err := s.reportRepo.UpsertSchedule(ctx, schedule); err != nil {
+		return nil, fmt.Errorf("failed to save report schedule: %w", err)
+	}
+	return schedule, nil
+}
+
+// GetSchedule returns the schedule of the form
+func (s *reportService) GetSchedule(ctx context.Context, formID, userID uuid.UUID) (*models.ReportSchedule, error) {
+	if _, err := s.getOwnedForm(ctx, formID, userID); err != nil {
+		return nil, err
+	}
+	return s.reportRepo.GetSchedule(ctx, formID)
+}
+
+// DeleteSchedule unenrolls the form, keeping the reports generated so far
+func (s *reportService) DeleteSchedule(ctx context.Context, formID, userID uuid.UUID) error {
+	if _, err := s.getOwnedForm(ctx, formID, userID); err != nil {
+		return err
+	}
+	return s.reportRepo.DeleteSchedule(ctx, formID)
+}
+
+// ListReports returns the latest reports of the form with short-lived
+// download links
+func (s *reportService) ListReports(ctx context.Context, formID, userID uuid.UUID) ([]*models.Report, error) {
+	if _, err := s.getOwnedForm(ctx, formID, userID); err != nil {
+		return nil, err
+	}
+
+	reports, err := s.reportRepo.ListReports(ctx, formID, reportListLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reports: %w", err)
+	}
+	for _, report := range reports {
+		report.DownloadURL, err = s.storage.PresignGet(ctx, report.ObjectKey, reportDownloadTTL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign report download: %w", err)
+		}
+	}
+	return reports, nil
+}
+
+// RunDueReports runs the due schedules. A schedule whose run fails keeps its
+// next run and is retried on the following call.
+func (s *reportService) RunDueReports(ctx context.Context) (int, error) {
+	now := s.now().UTC()
+	schedules, err := s.reportRepo.DueSchedules(ctx, now, reportBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find due report schedules: %w", err)
+	}
+
+	generated := 0
+	for _, schedule := range schedules {
+		ok, err := s.runSchedule(ctx, schedule, now)
+		if err != nil {
+			log.Printf("Report of form %s for the run at %s failed: %v", schedule.FormID, schedule.NextRunAt.Format(time.RFC3339), err)
+			continue
+		}
+		if ok {
+			generated++
+		}
+	}
+	return generated, nil
+}
+
+// runSchedule reports the period ending at the latest due run, then moves
+// the schedule to its next run after now. Runs missed while no scheduler was
+// running are not caught up: only the latest due period is reported.
+func (s *reportService) runSchedule(ctx context.Context, schedule *models.ReportSchedule, now time.Time) (bool, error) {
+	due := schedule.NextRunAt
+	runAt := due
+	next, err := schedule.NextRun(runAt)
+	for err == nil && !next.After(now) {
+		runAt = next
+		next, err = schedule.NextRun(runAt)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	form, err := s.formRepo.GetByID(ctx, schedule.FormID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("Removing the report schedule of deleted form %s", schedule.FormID)
+		if err := s.reportRepo.DeleteSchedule(ctx, schedule.FormID); err != nil && !errors.Is(err, repository.ErrScheduleNotFound) {
+			return false, fmt.Errorf("failed to remove report schedule: %w", err)
+		}
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get form: %w", err)
+	}
+
+	start, end := schedule.Period(runAt)
+	summary, err := s.aggregator.Summary(ctx, form.ID.String(), start, end)
+	if err != nil {
+		return false, err
+	}
+
+	generated := false
+	if summary.TotalResponses > 0 || schedule.IncludeEmpty {
+		if generated, err = s.generate(ctx, form, schedule, summary, start, end); err != nil {
+			return false, err
+		}
+	}
+
+	if _, err := s.reportRepo.AdvanceSchedule(ctx, schedule.FormID, due, next); err != nil {
+		return generated, fmt.Errorf("failed to advance report schedule: %w", err)
+	}
+	return generated, nil
+}
+
+// generate renders and stores the report of a period, then announces it to
+// the recipients. A period reported before, by a run that failed to advance
+// its schedule, is not announced again.
+func (s *reportService) generate(ctx context.Context, form *models.Form, schedule *models.ReportSchedule, summary *analytics.Summary, start, end time.Time) (bool, error) {
+	body, err := renderReport(schedule.Format, reportView{
+		Title:    form.Title,
+		Timezone: schedule.Timezone,
+		Start:    start,
+		End:      end,
+		Summary:  summary,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	key := fmt.Sprintf("reports/%s/%s.%s", form.ID, end.UTC().Format("20060102T150405Z"), schedule.Format)
+	if err := s.storage.Put(ctx, key, schedule.Format.ContentType(), body); err != nil {
+		return false, fmt.Errorf("failed to store report: %w", err)
+	}
+
+	report := &models.Report{
+		FormID:         form.ID,
+		PeriodStart:    start,
+		PeriodEnd:      end,
+		Format:         schedule.Format,
+		ObjectKey:      key,
+		SizeBytes:      int64(len(body)),
+		Responses:      summary.TotalResponses,
+		CompletionRate: summary.CompletionRate,
+	}
+	created, err := s.reportRepo.CreateReport(ctx, report)
+	if err != nil {
+		return false, fmt.Errorf("failed to record report: %w", err)
+	}
+	if !created || len(schedule.Recipients) == 0 {
+		return created, nil
+	}
+
+	link, err := s.storage.PresignGet(ctx, key, reportEmailLinkTTL)
+	if err != nil {
+		return true, fmt.Errorf("failed to sign report download: %w", err)
+	}
+	err = s.publisher.Publish(ctx, events.ReportReady, form.ID.String(), map[string]interface{}{
+		"form_id":         form.ID.String(),
+		"report_id":       report.ID.String(),
+		"title":           form.Title,
+		"frequency":       string(schedule.Frequency),
+		"period_start":    start.Format(time.RFC3339),
+		"period_end":      end.Format(time.RFC3339),
+		"timezone":        schedule.Timezone,
+		"format":          string(schedule.Format),
+		"recipients":      []string(schedule.Recipients),
+		"total_responses": summary.TotalResponses,
+		"completion_rate": summary.CompletionRate,
+		"download_url":    link,
+	})
+	if err != nil {
+		log.Printf("Failed to publish %s event for report %s: %v", events.ReportReady, report.ID, err)
+	}
+	return true, nil
+}
+
+// RunScheduler runs RunDueReports every interval until ctx is cancelled,
+// while this replica holds the scheduler lock. The lease outlives a few
+// ticks, so it passes to another replica only once its holder stops.
+func (s *reportService) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	defer func() {
+		if err := s.lock.Release(context.Background()); err != nil {
+			log.Printf("Failed to release the report scheduler lock: %v", err)
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			leader, err := s.lock.Acquire(ctx, 3*interval)
+			if err != nil {
+				log.Printf("Report scheduler lock unavailable: %v", err)
+				continue
+			}
+			if !leader {
+				continue
+			}
+			generated, err := s.RunDueReports(ctx)
+			if err != nil {
+				log.Printf("Report scheduler failed: %v", err)
+				continue
+			}
+			if generated > 0 {
+				log.Printf("Generated %d scheduled reports", generated)
+			}
+		}
+	}
+}
+
+func (s *reportService) getOwnedForm(ctx context.Context, formID, userID uuid.UUID) (*models.Form, error) {
+	form, err := s.formRepo.GetByID(ctx, formID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrFormNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get form: %w", err)
+	}
+	if !inScope(ctx, form) {
+		return nil, ErrFormNotFound
+	}
+	if form.UserID != userID {
+		return nil, ErrNotFormOwner
+	}
+	return form, nil
+}
+
+// reportView is the data a report is rendered from
+type reportView struct {
+	Title    string
+	Timezone string
+	Start    time.Time
+	End      time.Time
+	Summary  *analytics.Summary
+}
+
+// LocalStart and LocalEnd format the period in the time zone of the schedule
+func (v reportView) LocalStart() string { return v.local(v.Start) }
+func (v reportView) LocalEnd() string   { return v.local(v.End) }
+
+func (v reportView) local(t time.Time) string {
+	if loc, err := time.LoadLocation(v.Timezone); err == nil {
+		t = t.In(loc)
+	}
+	return t.Format("2 Jan 2006 15:04 MST")
+}
+
+// AverageCompletion formats the average completion time, empty without
+// completed responses
+func (v reportView) AverageCompletion() string {
+	if v.Summary.AverageCompletionTime == nil {
+		return ""
+	}
+	return time.Duration(*v.Summary.AverageCompletionTime * float64(time.Second)).Round(time.Second).String()
+}
+
+var htmlReport = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}} report</title></head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.LocalStart}} to {{.LocalEnd}}</p>
+<table>
+<tr><th>Responses</th><td>{{.Summary.TotalResponses}}</td></tr>
+<tr><th>Completed</th><td>{{.Summary.CompletedResponses}}</td></tr>
+<tr><th>Partial</th><td>{{.Summary.PartialResponses}}</td></tr>
+<tr><th>Completion rate</th><td>{{printf "%.1f" .Summary.CompletionRate}}%</td></tr>
+<tr><th>Unique respondents</th><td>{{.Summary.UniqueRespondents}}</td></tr>
+{{with .AverageCompletion}}<tr><th>Average completion time</th><td>{{.}}</td></tr>
+{{end}}</table>
+{{with .Summary.Trend}}<h2>Responses per day</h2>
+<table>
+{{range .}}<tr><td>{{.Date}}</td><td>{{.Count}}</td></tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+`))
+
+// renderReport renders the report in its format. CSV reports hold a header
+// and a single row of the period summary.
+func renderReport(format models.ReportFormat, view reportView) ([]byte, error) {
+	var b bytes.Buffer
+	if format != models.ReportFormatCSV {
+		if err := htmlReport.Execute(&b, view); err != nil {
+			return nil, fmt.Errorf("failed to render report: %w", err)
+		}
+		return b.Bytes(), nil
+	}
+
+	average := ""
+	if view.Summary.AverageCompletionTime != nil {
+		average = strconv.FormatFloat(*view.Summary.AverageCompletionTime, 'f', 1, 64)
+	}
+	w := csv.NewWriter(&b)
+	_ = w.Write([]string{
+		"form", "period_start", "period_end", "total_responses", "completed_responses",
+		"partial_responses", "completion_rate", "unique_respondents", "average_completion_seconds",
+	})
+	_ = w.Write([]string{
+		view.Title,
+		view.Start.Format(time.RFC3339),
+		view.End.Format(time.RFC3339),
+		strconv.Itoa(view.Summary.TotalResponses),
+		strconv.Itoa(view.Summary.CompletedResponses),
+		strconv.Itoa(view.Summary.PartialResponses),
+		strconv.FormatFloat(view.Summary.CompletionRate, 'f', 2, 64),
+		strconv.Itoa(view.Summary.UniqueRespondents),
+		average,
+	})
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("failed to render report: %w", err)
+	}
+	return b.Bytes(), nil
+}
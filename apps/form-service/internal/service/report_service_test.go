@@ -0,0 +1,347 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+	"github.com/Mir00r/X-Form-Backend/shared/storage"
+)
+
+// memoryReportRepository keeps schedules and reports in memory
+type memoryReportRepository struct {
+	mu        sync.Mutex
+	schedules map[uuid.UUID]models.ReportSchedule
+	reports   []models.Report
+}
+
+func newMemoryReportRepository() *memoryReportRepository {
+	return &memoryReportRepository{schedules: make(map[uuid.UUID]models.ReportSchedule)}
+}
+
+func (r *memoryReportRepository) UpsertSchedule(_ context.Context, schedule *models.ReportSchedule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.schedules[schedule.FormID] = *schedule
+	return nil
+}
+
+func (r *memoryReportRepository) GetSchedule(_ context.Context, formID uuid.UUID) (*models.ReportSchedule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	schedule, ok := r.schedules[formID]
+	if !ok {
+		return nil, repository.ErrScheduleNotFound
+	}
+	return &schedule, nil
+}
+
+func (r *memoryReportRepository) DeleteSchedule(_ context.Context, formID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.schedules[formID]; !ok {
+		return repository.ErrScheduleNotFound
+	}
+	delete(r.schedules, formID)
+	return nil
+}
+
+func (r *memoryReportRepository) DueSchedules(_ context.Context, now time.Time, limit int) ([]*models.ReportSchedule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var due []*models.ReportSchedule
+	for _, schedule := range r.schedules {
+		schedule := schedule
+		if !schedule.NextRunAt.After(now) {
+			due = append(due, &schedule)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].NextRunAt.Before(due[j].NextRunAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (r *memoryReportRepository) AdvanceSchedule(_ context.Context, formID uuid.UUID, from, next time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	schedule, ok := r.schedules[formID]
+	if !ok || !schedule.NextRunAt.Equal(from) {
+		return false, nil
+	}
+	schedule.NextRunAt = next
+	r.schedules[formID] = schedule
+	return true, nil
+}
+
+func (r *memoryReportRepository) CreateReport(_ context.Context, report *models.Report) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.reports {
+		if existing.FormID == report.FormID && existing.PeriodEnd.Equal(report.PeriodEnd) {
+			return false, nil
+		}
+	}
+	report.ID = uuid.New()
+	r.reports = append(r.reports, *report)
+	return true, nil
+}
+
+func (r *memoryReportRepository) ListReports(_ context.Context, formID uuid.UUID, limit int) ([]*models.Report, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var reports []*models.Report
+	for i := len(r.reports) - 1; i >= 0 && len(reports) < limit; i-- {
+		if r.reports[i].FormID == formID {
+			report := r.reports[i]
+			reports = append(reports, &report)
+		}
+	}
+	return reports, nil
+}
+
+type reportFixture struct {
+	*memoryStore
+	svc        *reportService
+	reports    *memoryReportRepository
+	aggregator *fakeAggregator
+	store      storage.Backend
+	publisher  *recordingPublisher
+	owner      uuid.UUID
+	form       *models.Form
+}
+
+func newReportFixture(t *testing.T) *reportFixture {
+	t.Helper()
+	store, err := storage.NewLocalStorage(t.TempDir(), "http://localhost:8001", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &reportFixture{
+		memoryStore: newMemoryStore(time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)),
+		reports:     newMemoryReportRepository(),
+		aggregator:  &fakeAggregator{responses: make(map[string]int)},
+		store:       store,
+		publisher:   &recordingPublisher{},
+		owner:       uuid.New(),
+	}
+	f.form = f.createNotifiedForm(t, f.owner, models.NotificationSettings{})
+	f.svc = NewReportService(f.forms, f.reports, f.aggregator, store, f.publisher, nil).(*reportService)
+	f.svc.now = f.clock.now
+	return f
+}
+
+func TestReportScheduleKeepsLocalTimeAcrossDST(t *testing.T) {
+	schedule := &models.ReportSchedule{Frequency: models.ReportFrequencyDaily, SendAt: "09:00", Timezone: "America/New_York"}
+
+	tests := []struct {
+		name  string
+		after time.Time
+		want  time.Time
+	}{
+		{"before spring forward", time.Date(2024, 3, 9, 12, 0, 0, 0, time.UTC), time.Date(2024, 3, 9, 14, 0, 0, 0, time.UTC)},
+		{"across spring forward", time.Date(2024, 3, 9, 14, 0, 0, 0, time.UTC), time.Date(2024, 3, 10, 13, 0, 0, 0, time.UTC)},
+		{"across fall back", time.Date(2024, 11, 2, 13, 0, 0, 0, time.UTC), time.Date(2024, 11, 3, 14, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := schedule.NextRun(tt.after)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("NextRun(%s) = %s, want %s", tt.after, got, tt.want)
+			}
+		})
+	}
+
+	// A day shortened by the transition is reported as one calendar day
+	start, end := schedule.Period(time.Date(2024, 3, 10, 13, 0, 0, 0, time.UTC))
+	if want := time.Date(2024, 3, 9, 14, 0, 0, 0, time.UTC); !start.Equal(want) || end.Sub(start) != 23*time.Hour {
+		t.Errorf("period = %s to %s, want the 23 hours from %s", start, end, want)
+	}
+
+	// A time skipped by the transition runs once the clocks have moved on
+	schedule.SendAt = "02:30"
+	got, err := schedule.NextRun(time.Date(2024, 3, 10, 5, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 3, 10, 7, 30, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("skipped run = %s, want %s (03:30 EDT)", got, want)
+	}
+
+	// Weekly schedules run on their weekday
+	schedule.SendAt, schedule.Frequency, schedule.Weekday = "09:00", models.ReportFrequencyWeekly, int(time.Monday)
+	got, err = schedule.NextRun(time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 3, 11, 13, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("weekly run = %s, want %s", got, want)
+	}
+}
+
+func TestUpsertReportSchedule(t *testing.T) {
+	f := newReportFixture(t)
+	ctx := context.Background()
+
+	schedule, err := f.svc.UpsertSchedule(ctx, f.form.ID, f.owner, ReportScheduleRequest{
+		Frequency:  models.ReportFrequencyDaily,
+		Timezone:   "Europe/Berlin",
+		Recipients: []string{" owner@example.com "},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schedule.SendAt != "09:00" || schedule.Format != models.ReportFormatHTML || schedule.Recipients[0] != "owner@example.com" {
+		t.Errorf("schedule = %+v, want the defaults and trimmed recipients", schedule)
+	}
+	if want := time.Date(2024, 3, 5, 8, 0, 0, 0, time.UTC); !schedule.NextRunAt.Equal(want) {
+		t.Errorf("first run = %s, want %s", schedule.NextRunAt, want)
+	}
+
+	if _, err := f.svc.UpsertSchedule(ctx, f.form.ID, uuid.New(), ReportScheduleRequest{Frequency: models.ReportFrequencyDaily}); !errors.Is(err, ErrNotFormOwner) {
+		t.Errorf("err = %v, want ErrNotFormOwner", err)
+	}
+	invalid := []ReportScheduleRequest{
+		{Frequency: "hourly"},
+		{Frequency: models.ReportFrequencyDaily, Timezone: "Mars/Olympus"},
+		{Frequency: models.ReportFrequencyDaily, SendAt: "9am"},
+		{Frequency: models.ReportFrequencyWeekly, Weekday: 7},
+		{Frequency: models.ReportFrequencyDaily, Recipients: []string{"not an address"}},
+	}
+	for _, req := range invalid {
+		if _, err := f.svc.UpsertSchedule(ctx, f.form.ID, f.owner, req); !errors.Is(err, ErrReportScheduleInvalid) {
+			t.Errorf("UpsertSchedule(%+v) err = %v, want ErrReportScheduleInvalid", req, err)
+		}
+	}
+}
+
+func TestRunDueReports(t *testing.T) {
+	f := newReportFixture(t)
+	ctx := context.Background()
+
+	_, err := f.svc.UpsertSchedule(ctx, f.form.ID, f.owner, ReportScheduleRequest{
+		Frequency:  models.ReportFrequencyDaily,
+		Recipients: []string{"owner@example.com"},
+		Format:     models.ReportFormatCSV,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing is due before the first run
+	if generated, err := f.svc.RunDueReports(ctx); err != nil || generated != 0 {
+		t.Fatalf("generated %d reports (err %v) before the first run", generated, err)
+	}
+
+	f.aggregator.responses[f.form.ID.String()] = 3
+	f.clock.t = time.Date(2024, 3, 5, 9, 0, 30, 0, time.UTC)
+	if generated, err := f.svc.RunDueReports(ctx); err != nil || generated != 1 {
+		t.Fatalf("generated %d reports (err %v), want 1", generated, err)
+	}
+	period := f.aggregator.periods[0]
+	if !period[0].Equal(time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)) || !period[1].Equal(time.Date(2024, 3, 5, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("aggregated %s to %s, want the day before the run", period[0], period[1])
+	}
+
+	reports, err := f.svc.ListReports(ctx, f.form.ID, f.owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 1 || reports[0].Responses != 3 || reports[0].DownloadURL == "" {
+		t.Fatalf("reports = %+v, want one report with a download link", reports)
+	}
+	object, err := f.store.Open(ctx, reports[0].ObjectKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer object.Close()
+	body, _ := io.ReadAll(object)
+	if !strings.HasPrefix(string(body), "form,period_start,") || !strings.Contains(string(body), "Feedback,2024-03-04T09:00:00Z,2024-03-05T09:00:00Z,3,") {
+		t.Errorf("report =\n%s", body)
+	}
+
+	if len(f.publisher.events) != 1 || f.publisher.events[0] != events.ReportReady {
+		t.Fatalf("published %v, want a report ready event", f.publisher.events)
+	}
+	if recipients := f.publisher.data[0]["recipients"].([]string); len(recipients) != 1 || recipients[0] != "owner@example.com" {
+		t.Errorf("recipients = %v", recipients)
+	}
+
+	schedule, _ := f.reports.GetSchedule(ctx, f.form.ID)
+	if want := time.Date(2024, 3, 6, 9, 0, 0, 0, time.UTC); !schedule.NextRunAt.Equal(want) {
+		t.Errorf("next run = %s, want %s", schedule.NextRunAt, want)
+	}
+}
+
+func TestRunDueReportsSkipsEmptyAndMissedPeriods(t *testing.T) {
+	f := newReportFixture(t)
+	ctx := context.Background()
+
+	if _, err := f.svc.UpsertSchedule(ctx, f.form.ID, f.owner, ReportScheduleRequest{Frequency: models.ReportFrequencyDaily}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Three runs were missed; only the latest period is aggregated, and
+	// skipped as it has no responses
+	f.clock.t = time.Date(2024, 3, 7, 10, 0, 0, 0, time.UTC)
+	if generated, err := f.svc.RunDueReports(ctx); err != nil || generated != 0 {
+		t.Fatalf("generated %d reports (err %v) for an empty period", generated, err)
+	}
+	if len(f.aggregator.periods) != 1 || !f.aggregator.periods[0][1].Equal(time.Date(2024, 3, 7, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("aggregated %v, want the period ending at the latest due run", f.aggregator.periods)
+	}
+	schedule, _ := f.reports.GetSchedule(ctx, f.form.ID)
+	if want := time.Date(2024, 3, 8, 9, 0, 0, 0, time.UTC); !schedule.NextRunAt.Equal(want) {
+		t.Errorf("next run = %s, want %s", schedule.NextRunAt, want)
+	}
+
+	// Owners opting into empty reports get them, without an email when
+	// the schedule has no recipients
+	if _, err := f.svc.UpsertSchedule(ctx, f.form.ID, f.owner, ReportScheduleRequest{Frequency: models.ReportFrequencyDaily, IncludeEmpty: true}); err != nil {
+		t.Fatal(err)
+	}
+	f.clock.t = time.Date(2024, 3, 8, 9, 0, 0, 0, time.UTC)
+	if generated, err := f.svc.RunDueReports(ctx); err != nil || generated != 1 {
+		t.Fatalf("generated %d reports (err %v), want the empty report", generated, err)
+	}
+	if len(f.publisher.events) != 0 {
+		t.Errorf("published %v for a report without recipients", f.publisher.events)
+	}
+
+	// A failing aggregation keeps the run due for a retry
+	f.aggregator.err = errors.New("analytics unavailable")
+	f.clock.t = time.Date(2024, 3, 9, 9, 0, 0, 0, time.UTC)
+	if _, err := f.svc.RunDueReports(ctx); err != nil {
+		t.Fatal(err)
+	}
+	schedule, _ = f.reports.GetSchedule(ctx, f.form.ID)
+	if !schedule.NextRunAt.Equal(f.clock.t) {
+		t.Errorf("next run = %s after a failed run, want it unchanged", schedule.NextRunAt)
+	}
+
+	// Schedules of deleted forms are removed
+	if err := f.forms.Delete(ctx, f.form.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.svc.RunDueReports(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.reports.GetSchedule(ctx, f.form.ID); !errors.Is(err, repository.ErrScheduleNotFound) {
+		t.Errorf("schedule of a deleted form: err = %v, want ErrScheduleNotFound", err)
+	}
+}
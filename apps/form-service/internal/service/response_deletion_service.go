@@ -0,0 +1,503 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/access"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/confirmation"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/responses"
+)
+
+// responseDeletionStaleAfter is how long a running deletion may go without
+// saving its progress before another replica takes it over
+const responseDeletionStaleAfter = 15 * time.Minute
+
+var (
+	// ErrResponseDeletionInvalid is returned for deletions that can't be
+	// started
+	ErrResponseDeletionInvalid = errors.New("invalid response deletion")
+	// ErrConfirmationRequired is returned for deletions started without the
+	// token of a dry run
+	ErrConfirmationRequired = errors.New("confirmation_token is required; get one with a dry run")
+	// ErrConfirmationMismatch is returned for tokens of the dry run of
+	// another form, caller or filter
+	ErrConfirmationMismatch = errors.New("confirmation token was issued for another form, user or filter")
+	// ErrMatchesChanged is returned when more responses match the filter
+	// than the dry run counted
+	ErrMatchesChanged = errors.New("more responses match the filter than the dry run counted; run it again")
+	// ErrDeletionCapExceeded is returned for deletions of a greater share
+	// of the responses of a form than the cap, without an override
+	ErrDeletionCapExceeded = errors.New("deletion exceeds the share of the responses of a form deleted at once")
+	// ErrResponseDeletionNotFailed is returned when resuming a deletion
+	// that did not fail
+	ErrResponseDeletionNotFailed = errors.New("only failed response deletions can be resumed")
+)
+
+// ResponseDeletionConfig configures the bulk deletion of responses
+type ResponseDeletionConfig struct {
+	// Secret signs the confirmation tokens of dry runs, which stay valid
+	// for TokenTTL
+	Secret   string
+	TokenTTL time.Duration
+	// MaxPercent is the largest share of the responses of a form, in
+	// percent, deleted at once without the override of an admin of its
+	// organization
+	MaxPercent int
+	// BatchSize is the number of responses deleted at a time
+	BatchSize int
+}
+
+// ResponseDeletionService defines the interface for the bulk deletion of the
+// responses of forms. A deletion takes two steps: a dry run counts the
+// responses matching a filter and signs a confirmation token, then the
+// deletion is started with the token. Deletions run as jobs, claimed by a
+// single replica and deleting the responses a batch at a time. Each batch
+// is dropped from the response projection through form.responses.deleted
+// and released from the response quota of the organization.
+type ResponseDeletionService interface {
+	// DryRun counts the responses the filter matches, deleting nothing
+	DryRun(ctx context.Context, formID, userID uuid.UUID, filter models.ResponseFilter) (*ResponseDeletionPreview, error)
+	// Start queues the deletion confirmed by the token of a dry run
+	Start(ctx context.Context, formID, userID uuid.UUID, req BulkDeleteRequest) (*models.ResponseDeletionJob, error)
+	// PurgeTest queues the deletion of every test response of the form,
+	// without a dry run
+	PurgeTest(ctx context.Context, formID, userID uuid.UUID) (*models.ResponseDeletionJob, error)
+	GetJob(ctx context.Context, formID, jobID, userID uuid.UUID) (*models.ResponseDeletionJob, error)
+	// Resume queues a failed deletion again. It goes on with the batch it
+	// failed on.
+	Resume(ctx context.Context, formID, jobID, userID uuid.UUID) (*models.ResponseDeletionJob, error)
+	// RunJobs runs the queued deletions, checking for them every interval
+	// until ctx is cancelled
+	RunJobs(ctx context.Context, interval time.Duration)
+}
+
+// BulkDeleteRequest starts the deletion of the responses of a form matching
+// the filter, or counts them with DryRun
+type BulkDeleteRequest struct {
+	models.ResponseFilter
+	DryRun bool `json:"dry_run"`
+	// ConfirmationToken is the token of the dry run of the same filter,
+	// required to start the deletion
+	ConfirmationToken string `json:"confirmation_token"`
+	// OverrideCap lets an owner or an admin of the organization of the
+	// form delete a greater share of its responses than the cap
+	OverrideCap bool `json:"override_cap"`
+}
+
+// ResponseDeletionPreview is what a deletion would delete
+type ResponseDeletionPreview struct {
+	Matched int `json:"matched"`
+	Total   int `json:"total"`
+	// ExceedsCap is set when the responses matched are a greater share of
+	// the responses of the form than MaxPercent, so deleting them needs
+	// the override of an admin of the organization
+	ExceedsCap bool `json:"exceeds_cap"`
+	MaxPercent int  `json:"max_percent"`
+	// ConfirmationToken starts the deletion until ExpiresAt. It is not
+	// issued when nothing matches.
+	ConfirmationToken string     `json:"confirmation_token,omitempty"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"`
+}
+
+// responseDeletionService implements ResponseDeletionService interface
+type responseDeletionService struct {
+	guard     formGuard
+	orgs      repository.OrganizationRepository
+	jobs      repository.ResponseDeletionRepository
+	store     responses.Store
+	publisher events.Publisher
+	auditor   events.Auditor
+	usage     *UsageMeter
+	config    ResponseDeletionConfig
+	now       func() time.Time
+}
+
+// NewResponseDeletionService creates a new response deletion service
+// instance deleting the responses held by store. The responses deleted are
+// released from the quotas metered by usage, which may be nil.
+func NewResponseDeletionService(formRepo repository.FormRepository, collaboratorRepo repository.CollaboratorRepository, orgRepo repository.OrganizationRepository, jobs repository.ResponseDeletionRepository, store responses.Store, publisher events.Publisher, auditor events.Auditor, usage *UsageMeter, config ResponseDeletionConfig) ResponseDeletionService {
+	return &responseDeletionService{
+		guard:     formGuard{forms: formRepo, collaborators: collaboratorRepo, orgs: organizationScope{orgs: orgRepo}},
+		orgs:      orgRepo,
+		jobs:      jobs,
+		store:     store,
+		publisher: publisher,
+		auditor:   auditor,
+		usage:     usage,
+		config:    config,
+		now:       time.Now,
+	}
+}
+
+// DryRun counts the matching responses and signs a token confirming their
+// deletion by the caller
+func (s *responseDeletionService) DryRun(ctx context.Context, formID, userID uuid.UUID, filter models.ResponseFilter) (*ResponseDeletionPreview, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrResponseDeletionInvalid, err)
+	}
+	if _, err := s.guard.authorize(ctx, formID, userID, access.DeleteResponses); err != nil {
+		return nil, err
+	}
+
+	match, err := s.store.Match(ctx, formID.String(), filter, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to match responses: %w", err)
+	}
+	preview := &ResponseDeletionPreview{
+		Matched:    match.Matched,
+		Total:      match.Total,
+		ExceedsCap: s.exceedsCap(filter, match.Matched, match.Total),
+		MaxPercent: s.config.MaxPercent,
+	}
+	if match.Matched == 0 {
+		return preview, nil
+	}
+
+	expiresAt := s.now().Add(s.config.TokenTTL).UTC()
+	preview.ConfirmationToken, err = confirmation.Sign(s.config.Secret, confirmation.Claims{
+		TokenID:   uuid.New(),
+		FormID:    formID,
+		UserID:    userID,
+		Filter:    filter.Hash(),
+		Matched:   match.Matched,
+		ExpiresAt: expiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign confirmation token: %w", err)
+	}
+	preview.ExpiresAt = &expiresAt
+	return preview, nil
+}
+
+// Start checks the token and counts the matching responses again: the job
+// deletes no more than the dry run counted, and none when more match now
+func (s *responseDeletionService) Start(ctx context.Context, formID, userID uuid.UUID, req BulkDeleteRequest) (*models.ResponseDeletionJob, error) {
+	filter := req.ResponseFilter
+	if err := filter.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrResponseDeletionInvalid, err)
+	}
+	form, err := s.guard.authorize(ctx, formID, userID, access.DeleteResponses)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ConfirmationToken == "" {
+		return nil, ErrConfirmationRequired
+	}
+	claims, err := confirmation.Verify(s.config.Secret, req.ConfirmationToken, s.now())
+	if err != nil {
+		return nil, err
+	}
+	if claims.FormID != formID || claims.UserID != userID || claims.Filter != filter.Hash() {
+		return nil, ErrConfirmationMismatch
+	}
+
+	match, err := s.store.Match(ctx, formID.String(), filter, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to match responses: %w", err)
+	}
+	if match.Matched > claims.Matched {
+		return nil, fmt.Errorf("%w: %d match, the dry run counted %d", ErrMatchesChanged, match.Matched, claims.Matched)
+	}
+	if match.Matched == 0 {
+		return nil, fmt.Errorf("%w: no responses match the filter anymore", ErrResponseDeletionInvalid)
+	}
+
+	overridden := false
+	if s.exceedsCap(filter, match.Matched, match.Total) {
+		if !req.OverrideCap {
+			return nil, fmt.Errorf("%w: %d of %d responses match, more than %d%%; an owner or an admin of the organization can override the cap",
+				ErrDeletionCapExceeded, match.Matched, match.Total, s.config.MaxPercent)
+		}
+		if err := s.authorizeOverride(ctx, form, userID); err != nil {
+			return nil, err
+		}
+		overridden = true
+	}
+
+	job := &models.ResponseDeletionJob{
+		FormID:         formID,
+		OrganizationID: form.OrganizationID,
+		Status:         models.ResponseDeletionQueued,
+		Filter:         datatypes.NewJSONType(filter),
+		ConfirmationID: claims.TokenID,
+		RequestedBy:    userID,
+		CapOverridden:  overridden,
+		Matched:        match.Matched,
+		Total:          match.Total,
+	}
+	return s.queue(ctx, job)
+}
+
+// PurgeTest queues the deletion of the test responses matching now. Test
+// responses are neither counted nor seen by respondents, so there is
+// nothing to confirm.
+func (s *responseDeletionService) PurgeTest(ctx context.Context, formID, userID uuid.UUID) (*models.ResponseDeletionJob, error) {
+	form, err := s.guard.authorize(ctx, formID, userID, access.PurgeTestResponses)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := models.ResponseFilter{TestOnly: true}
+	match, err := s.store.Match(ctx, formID.String(), filter, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to match responses: %w", err)
+	}
+	if match.Matched == 0 {
+		return nil, fmt.Errorf("%w: the form has no test responses", ErrResponseDeletionInvalid)
+	}
+
+	job := &models.ResponseDeletionJob{
+		FormID:         formID,
+		OrganizationID: form.OrganizationID,
+		Status:         models.ResponseDeletionQueued,
+		Filter:         datatypes.NewJSONType(filter),
+		ConfirmationID: uuid.New(),
+		RequestedBy:    userID,
+		Matched:        match.Matched,
+		Total:          match.Total,
+	}
+	return s.queue(ctx, job)
+}
+
+// queue creates a deletion job and audits it
+func (s *responseDeletionService) queue(ctx context.Context, job *models.ResponseDeletionJob) (*models.ResponseDeletionJob, error) {
+	if err := s.jobs.Create(ctx, job); err != nil {
+		if errors.Is(err, repository.ErrConfirmationUsed) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to create response deletion: %w", err)
+	}
+
+	s.auditor.Record(ctx, events.AuditEvent{
+		Action:       events.AuditResponseDeletionStarted,
+		Actor:        job.RequestedBy.String(),
+		ResourceType: "form",
+		ResourceID:   job.FormID.String(),
+		After:        deletionSummary(job),
+	})
+	return job, nil
+}
+
+// GetJob returns a deletion of the form with its progress
+func (s *responseDeletionService) GetJob(ctx context.Context, formID, jobID, userID uuid.UUID) (*models.ResponseDeletionJob, error) {
+	if _, err := s.guard.authorize(ctx, formID, userID, access.DeleteResponses); err != nil {
+		return nil, err
+	}
+	return s.getJob(ctx, formID, jobID)
+}
+
+// Resume queues a failed deletion; a replica running jobs claims it on its
+// next check
+func (s *responseDeletionService) Resume(ctx context.Context, formID, jobID, userID uuid.UUID) (*models.ResponseDeletionJob, error) {
+	if _, err := s.guard.authorize(ctx, formID, userID, access.DeleteResponses); err != nil {
+		return nil, err
+	}
+	if _, err := s.getJob(ctx, formID, jobID); err != nil {
+		return nil, err
+	}
+
+	resumed, err := s.jobs.Resume(ctx, jobID, s.now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to resume response deletion: %w", err)
+	}
+	job, err := s.jobs.Get(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if !resumed {
+		return job, ErrResponseDeletionNotFailed
+	}
+	return job, nil
+}
+
+func (s *responseDeletionService) getJob(ctx context.Context, formID, jobID uuid.UUID) (*models.ResponseDeletionJob, error) {
+	job, err := s.jobs.Get(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.FormID != formID {
+		return nil, repository.ErrResponseDeletionNotFound
+	}
+	return job, nil
+}
+
+// RunJobs claims jobs one at a time and runs each to its end
+func (s *responseDeletionService) RunJobs(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for ctx.Err() == nil {
+				now := s.now().UTC()
+				job, err := s.jobs.Claim(ctx, now, now.Add(-responseDeletionStaleAfter))
+				if err != nil {
+					log.Printf("Failed to claim response deletion: %v", err)
+					break
+				}
+				if job == nil {
+					break
+				}
+				s.runJob(ctx, job)
+			}
+		}
+	}
+}
+
+// runJob deletes the responses of a claimed job a batch at a time. The
+// batch is saved before it is deleted, so a job failing or stopping
+// meanwhile deletes it again when it resumes; deleting a response twice
+// deletes it once. A job whose progress can't be saved is left running,
+// and taken over once it went stale.
+func (s *responseDeletionService) runJob(ctx context.Context, job *models.ResponseDeletionJob) {
+	filter := job.Filter.Data()
+	log.Printf("Running response deletion %s of form %s requested by %s", job.ID, job.FormID, job.RequestedBy)
+
+	for ctx.Err() == nil {
+		batch := job.Pending.Data()
+		if len(batch) == 0 {
+			remaining := job.Matched - job.Deleted
+			if remaining <= 0 {
+				s.finish(ctx, job, models.ResponseDeletionCompleted, "")
+				return
+			}
+			match, err := s.store.Match(ctx, job.FormID.String(), filter, min(remaining, s.config.BatchSize))
+			if err != nil {
+				s.finish(ctx, job, models.ResponseDeletionFailed, fmt.Sprintf("failed to match responses: %v", err))
+				return
+			}
+			if len(match.Responses) == 0 {
+				s.finish(ctx, job, models.ResponseDeletionCompleted, "")
+				return
+			}
+			batch = match.Responses
+			job.Pending = datatypes.NewJSONType(batch)
+			if err := s.jobs.SaveProgress(ctx, job); err != nil {
+				log.Printf("Response deletion %s: failed to save progress: %v", job.ID, err)
+				return
+			}
+		}
+
+		ids := make([]string, len(batch))
+		for i, response := range batch {
+			ids[i] = response.ID
+		}
+		if _, err := s.store.Delete(ctx, job.FormID.String(), ids); err != nil {
+			s.finish(ctx, job, models.ResponseDeletionFailed, fmt.Sprintf("failed to delete responses: %v", err))
+			return
+		}
+		job.Deleted += len(batch)
+		s.deleted(ctx, job, batch)
+
+		job.Pending = datatypes.NewJSONType([]models.DeletedResponse(nil))
+		if err := s.jobs.SaveProgress(ctx, job); err != nil {
+			log.Printf("Response deletion %s: failed to save progress: %v", job.ID, err)
+			return
+		}
+	}
+}
+
+// deleted releases the responses of a batch from the quota and tells the
+// projections they are gone. Both tolerate a batch deleted twice.
+func (s *responseDeletionService) deleted(ctx context.Context, job *models.ResponseDeletionJob, batch []models.DeletedResponse) {
+	s.usage.releaseResponses(ctx, job.OrganizationID, batch)
+
+	ids := make([]string, len(batch))
+	for i, response := range batch {
+		ids[i] = response.ID
+	}
+	err := s.publisher.Publish(ctx, events.ResponsesDeleted, job.FormID.String(), map[string]interface{}{
+		"form_id":            job.FormID.String(),
+		"organization_id":    job.OrganizationID.String(),
+		"deletion_id":        job.ID.String(),
+		"response_ids":       ids,
+		"count":              len(batch),
+		"deleted":            job.Deleted,
+		"matched":            job.Matched,
+		"requested_by":       job.RequestedBy.String(),
+		"test_only":          job.Filter.Data().TestOnly,
+		"deletion_completed": job.Deleted >= job.Matched,
+	})
+	if err != nil {
+		log.Printf("Failed to publish %s event for form %s: %v", events.ResponsesDeleted, job.FormID, err)
+	}
+}
+
+// finish records the final status of a job and audits it. A failed job
+// keeps its pending batch for when it is resumed.
+func (s *responseDeletionService) finish(ctx context.Context, job *models.ResponseDeletionJob, status models.ResponseDeletionStatus, message string) {
+	finishedAt := s.now().UTC()
+	job.Status = status
+	job.Error = message
+	job.FinishedAt = &finishedAt
+	if err := s.jobs.Finish(ctx, job); err != nil {
+		log.Printf("Response deletion %s: failed to record its end: %v", job.ID, err)
+		return
+	}
+	log.Printf("Response deletion %s %s: %d of %d responses deleted", job.ID, status, job.Deleted, job.Matched)
+
+	s.auditor.Record(ctx, events.AuditEvent{
+		Action:       events.AuditResponsesDeleted,
+		Actor:        job.RequestedBy.String(),
+		ResourceType: "form",
+		ResourceID:   job.FormID.String(),
+		After:        deletionSummary(job),
+	})
+}
+
+// exceedsCap reports whether deleting matched of the total responses of a
+// form needs an override. Test submissions are deleted without a cap.
+func (s *responseDeletionService) exceedsCap(filter models.ResponseFilter, matched, total int) bool {
+	if filter.TestOnly {
+		return false
+	}
+	return matched*100 > s.config.MaxPercent*total
+}
+
+// authorizeOverride checks the user is an owner or an admin of the
+// organization of the form
+func (s *responseDeletionService) authorizeOverride(ctx context.Context, form *models.Form, userID uuid.UUID) error {
+	member, err := s.orgs.GetMember(ctx, form.OrganizationID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrInsufficientOrganizationRole
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get member: %w", err)
+	}
+	if !member.Role.CanOverrideDeletionCap() {
+		return ErrInsufficientOrganizationRole
+	}
+	return nil
+}
+
+// deletionSummary is what the audit log keeps of a deletion
+func deletionSummary(job *models.ResponseDeletionJob) map[string]interface{} {
+	summary := map[string]interface{}{
+		"deletion_id":    job.ID.String(),
+		"status":         job.Status,
+		"filter":         job.Filter.Data(),
+		"matched":        job.Matched,
+		"total":          job.Total,
+		"deleted":        job.Deleted,
+		"cap_overridden": job.CapOverridden,
+	}
+	if job.Error != "" {
+		summary["error"] = job.Error
+	}
+	return summary
+}
@@ -0,0 +1,525 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/confirmation"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/responses"
+)
+
+// memoryResponseDeletionRepository keeps bulk deletion jobs in memory
+type memoryResponseDeletionRepository struct {
+	mu   sync.Mutex
+	jobs map[uuid.UUID]models.ResponseDeletionJob
+}
+
+func newMemoryResponseDeletionRepository() *memoryResponseDeletionRepository {
+	return &memoryResponseDeletionRepository{jobs: make(map[uuid.UUID]models.ResponseDeletionJob)}
+}
+
+func (r *memoryResponseDeletionRepository) Create(_ context.Context, job *models.ResponseDeletionJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.jobs {
+		if existing.ConfirmationID == job.ConfirmationID {
+			return repository.ErrConfirmationUsed
+		}
+	}
+	job.ID = uuid.New()
+	r.jobs[job.ID] = *job
+	return nil
+}
+
+func (r *memoryResponseDeletionRepository) Get(_ context.Context, id uuid.UUID) (*models.ResponseDeletionJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, repository.ErrResponseDeletionNotFound
+	}
+	return &job, nil
+}
+
+func (r *memoryResponseDeletionRepository) Claim(_ context.Context, now, staleBefore time.Time) (*models.ResponseDeletionJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, job := range r.jobs {
+		if job.Status == models.ResponseDeletionQueued || (job.Status == models.ResponseDeletionRunning && job.UpdatedAt.Before(staleBefore)) {
+			job.Status, job.StartedAt, job.UpdatedAt = models.ResponseDeletionRunning, &now, now
+			r.jobs[id] = job
+			return &job, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryResponseDeletionRepository) SaveProgress(_ context.Context, job *models.ResponseDeletionJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := r.jobs[job.ID]
+	if stored.Status == models.ResponseDeletionRunning {
+		stored.Deleted, stored.Pending = job.Deleted, job.Pending
+		r.jobs[job.ID] = stored
+	}
+	return nil
+}
+
+func (r *memoryResponseDeletionRepository) Finish(_ context.Context, job *models.ResponseDeletionJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := r.jobs[job.ID]
+	if stored.Status == models.ResponseDeletionRunning {
+		stored.Status, stored.Deleted, stored.Pending, stored.Error, stored.FinishedAt = job.Status, job.Deleted, job.Pending, job.Error, job.FinishedAt
+		r.jobs[job.ID] = stored
+	}
+	return nil
+}
+
+func (r *memoryResponseDeletionRepository) Resume(_ context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok || job.Status != models.ResponseDeletionFailed {
+		return false, nil
+	}
+	job.Status, job.Error, job.FinishedAt, job.UpdatedAt = models.ResponseDeletionQueued, "", nil, now
+	r.jobs[id] = job
+	return true, nil
+}
+
+// memoryResponseStore keeps the responses of forms in memory. failDelete,
+// when set, fails the deletions it returns an error for, numbered from 1.
+type memoryResponseStore struct {
+	mu         sync.Mutex
+	responses  map[string][]models.DeletedResponse
+	deletes    int
+	failDelete func(call int) error
+}
+
+func newMemoryResponseStore() *memoryResponseStore {
+	return &memoryResponseStore{responses: make(map[string][]models.DeletedResponse)}
+}
+
+// submit adds n responses to the form, a day apart from at
+func (s *memoryResponseStore) submit(formID uuid.UUID, n int, at time.Time, test bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := formID.String()
+	for i := 0; i < n; i++ {
+		s.responses[key] = append(s.responses[key], models.DeletedResponse{
+			ID:          fmt.Sprintf("resp-%d", len(s.responses[key])+1),
+			SubmittedAt: at.Add(time.Duration(i) * 24 * time.Hour),
+			Test:        test,
+		})
+	}
+	sort.Slice(s.responses[key], func(i, j int) bool { return s.responses[key][i].SubmittedAt.Before(s.responses[key][j].SubmittedAt) })
+}
+
+func (s *memoryResponseStore) count(formID uuid.UUID) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.responses[formID.String()])
+}
+
+func (s *memoryResponseStore) Match(_ context.Context, formID string, filter models.ResponseFilter, limit int) (*responses.Match, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make(map[string]bool)
+	for _, id := range filter.ResponseIDs {
+		ids[id] = true
+	}
+	match := &responses.Match{Total: len(s.responses[formID])}
+	for _, response := range s.responses[formID] {
+		if (filter.SubmittedBefore != nil && !response.SubmittedAt.Before(*filter.SubmittedBefore)) ||
+			(filter.SubmittedAfter != nil && !response.SubmittedAt.After(*filter.SubmittedAfter)) ||
+			(len(ids) > 0 && !ids[response.ID]) || (filter.TestOnly && !response.Test) {
+			continue
+		}
+		match.Matched++
+		if len(match.Responses) < limit {
+			match.Responses = append(match.Responses, response)
+		}
+	}
+	return match, nil
+}
+
+func (s *memoryResponseStore) Delete(_ context.Context, formID string, ids []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deletes++
+	if s.failDelete != nil {
+		if err := s.failDelete(s.deletes); err != nil {
+			return 0, err
+		}
+	}
+	deleted := make(map[string]bool)
+	for _, id := range ids {
+		deleted[id] = true
+	}
+	kept := s.responses[formID][:0]
+	for _, response := range s.responses[formID] {
+		if !deleted[response.ID] {
+			kept = append(kept, response)
+		}
+	}
+	n := len(s.responses[formID]) - len(kept)
+	s.responses[formID] = kept
+	return n, nil
+}
+
+type responseDeletionFixture struct {
+	*memoryStore
+	jobs      *memoryResponseDeletionRepository
+	store     *memoryResponseStore
+	usage     *memoryUsageRepository
+	meter     *UsageMeter
+	publisher *recordingPublisher
+	auditor   *recordingAuditor
+	svc       *responseDeletionService
+	org       *models.Organization
+}
+
+// newResponseDeletionFixture creates a deletion service deleting batches of
+// 4 responses and at most 50% of the responses of a form without an
+// override, in an organization owned by the owner
+func newResponseDeletionFixture(t *testing.T, owner uuid.UUID) *responseDeletionFixture {
+	t.Helper()
+	f := &responseDeletionFixture{memoryStore: newMemoryStore(time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))}
+	f.jobs = newMemoryResponseDeletionRepository()
+	f.store = newMemoryResponseStore()
+	f.usage = newMemoryUsageRepository(f.forms)
+	f.meter = NewUsageMeter(newMemoryUsageCounter(), f.usage, f.forms, f.orgs, nil, false)
+	f.meter.now = f.clock.now
+	f.publisher = &recordingPublisher{}
+	f.auditor = &recordingAuditor{}
+	f.svc = NewResponseDeletionService(f.forms, nil, f.orgs, f.jobs, f.store, f.publisher, f.auditor, f.meter, ResponseDeletionConfig{
+		Secret:     "secret",
+		TokenTTL:   10 * time.Minute,
+		MaxPercent: 50,
+		BatchSize:  4,
+	}).(*responseDeletionService)
+	f.svc.now = f.clock.now
+	f.org = f.createOrganization(t, owner, nil)
+	return f
+}
+
+// form creates a form of the user in the organization of the fixture with
+// n responses submitted this month, metered against its quota
+func (f *responseDeletionFixture) form(t *testing.T, userID uuid.UUID, n int) *models.Form {
+	t.Helper()
+	ctx := context.Background()
+	form := f.createForm(t, &models.Form{UserID: userID, OrganizationID: f.org.ID, Title: "Survey", Status: models.FormStatusPublished})
+	f.store.submit(form.ID, n, f.clock.now().AddDate(0, 0, -n), false)
+	for _, response := range f.store.responses[form.ID.String()] {
+		if err := f.meter.admitResponse(ctx, form, response.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := f.meter.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	return form
+}
+
+// responsesUsed returns the responses metered this month, counted and
+// flushed
+func (f *responseDeletionFixture) responsesUsed(t *testing.T) (counted, flushed int64) {
+	t.Helper()
+	key := UsageKeyOf(f.org.ID, f.clock.now())
+	current, err := f.meter.current(context.Background(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	row, _ := f.usage.Get(context.Background(), key)
+	return current.Responses, row.Responses
+}
+
+// confirm dry runs the filter and starts the deletion with its token
+func (f *responseDeletionFixture) confirm(t *testing.T, formID, userID uuid.UUID, req BulkDeleteRequest) (*models.ResponseDeletionJob, error) {
+	t.Helper()
+	preview, err := f.svc.DryRun(context.Background(), formID, userID, req.ResponseFilter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.ConfirmationToken = preview.ConfirmationToken
+	return f.svc.Start(context.Background(), formID, userID, req)
+}
+
+// run runs the queued jobs until none is left
+func (f *responseDeletionFixture) run(t *testing.T) {
+	t.Helper()
+	ctx := context.Background()
+	for {
+		job, err := f.jobs.Claim(ctx, f.clock.now(), f.clock.now().Add(-responseDeletionStaleAfter))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job == nil {
+			return
+		}
+		f.svc.runJob(ctx, job)
+	}
+}
+
+// deletedIDs returns the response IDs of the form.responses.deleted events
+func (f *responseDeletionFixture) deletedIDs() []string {
+	var ids []string
+	for i, event := range f.publisher.events {
+		if event == events.ResponsesDeleted {
+			ids = append(ids, f.publisher.data[i]["response_ids"].([]string)...)
+		}
+	}
+	return ids
+}
+
+func TestBulkDeleteResponses(t *testing.T) {
+	ctx := context.Background()
+	owner := uuid.New()
+	f := newResponseDeletionFixture(t, owner)
+	form := f.form(t, owner, 10)
+
+	before := f.clock.now().AddDate(0, 0, -5)
+	filter := models.ResponseFilter{SubmittedBefore: &before}
+	preview, err := f.svc.DryRun(ctx, form.ID, owner, filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Matched != 5 || preview.Total != 10 || preview.ExceedsCap || preview.ConfirmationToken == "" {
+		t.Fatalf("preview = %+v, want 5 of 10 matched with a token", preview)
+	}
+	if f.store.count(form.ID) != 10 {
+		t.Fatal("the dry run deleted responses")
+	}
+
+	if _, err := f.svc.Start(ctx, form.ID, owner, BulkDeleteRequest{ResponseFilter: filter}); !errors.Is(err, ErrConfirmationRequired) {
+		t.Errorf("start without a token: err = %v, want ErrConfirmationRequired", err)
+	}
+	other := f.clock.now().AddDate(0, 0, -2)
+	if _, err := f.svc.Start(ctx, form.ID, owner, BulkDeleteRequest{
+		ResponseFilter:    models.ResponseFilter{SubmittedBefore: &other},
+		ConfirmationToken: preview.ConfirmationToken,
+	}); !errors.Is(err, ErrConfirmationMismatch) {
+		t.Errorf("start with the token of another filter: err = %v, want ErrConfirmationMismatch", err)
+	}
+	if _, err := f.svc.Start(ctx, form.ID, uuid.New(), BulkDeleteRequest{ResponseFilter: filter, ConfirmationToken: preview.ConfirmationToken}); !errors.Is(err, ErrNotFormOwner) {
+		t.Errorf("start by a stranger: err = %v, want ErrNotFormOwner", err)
+	}
+
+	req := BulkDeleteRequest{ResponseFilter: filter, ConfirmationToken: preview.ConfirmationToken}
+	job, err := f.svc.Start(ctx, form.ID, owner, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != models.ResponseDeletionQueued || job.Matched != 5 || job.Total != 10 {
+		t.Errorf("job = %+v, want 5 of 10 queued", job)
+	}
+	if _, err := f.svc.Start(ctx, form.ID, owner, req); !errors.Is(err, repository.ErrConfirmationUsed) {
+		t.Errorf("second start with the token: err = %v, want ErrConfirmationUsed", err)
+	}
+
+	f.run(t)
+	job, _ = f.svc.GetJob(ctx, form.ID, job.ID, owner)
+	if job.Status != models.ResponseDeletionCompleted || job.Deleted != 5 || f.store.count(form.ID) != 5 {
+		t.Errorf("job %s deleted %d, %d responses left; want 5 deleted and 5 left", job.Status, job.Deleted, f.store.count(form.ID))
+	}
+	if ids := f.deletedIDs(); len(ids) != 5 || ids[0] != "resp-1" {
+		t.Errorf("form.responses.deleted for %v, want the 5 oldest responses", ids)
+	}
+	if counted, flushed := f.responsesUsed(t); counted != 5 || flushed != 5 {
+		t.Errorf("%d responses counted and %d flushed, want 5 released", counted, flushed)
+	}
+	if actions := f.auditor.actions(); len(actions) != 2 || actions[0] != events.AuditResponseDeletionStarted || actions[1] != events.AuditResponsesDeleted {
+		t.Errorf("audited %v", actions)
+	}
+	if _, err := f.svc.GetJob(ctx, f.form(t, owner, 0).ID, job.ID, owner); !errors.Is(err, repository.ErrResponseDeletionNotFound) {
+		t.Errorf("job of another form: err = %v, want ErrResponseDeletionNotFound", err)
+	}
+}
+
+func TestBulkDeleteTokenExpires(t *testing.T) {
+	ctx := context.Background()
+	owner := uuid.New()
+	f := newResponseDeletionFixture(t, owner)
+	form := f.form(t, owner, 4)
+
+	filter := models.ResponseFilter{ResponseIDs: []string{"resp-1"}}
+	preview, err := f.svc.DryRun(ctx, form.ID, owner, filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.clock.advance(10 * time.Minute)
+	if _, err := f.svc.Start(ctx, form.ID, owner, BulkDeleteRequest{ResponseFilter: filter, ConfirmationToken: preview.ConfirmationToken}); !errors.Is(err, confirmation.ErrExpiredToken) {
+		t.Fatalf("start after the token expired: err = %v, want ErrExpiredToken", err)
+	}
+	f.run(t)
+	if len(f.jobs.jobs) != 0 || f.store.count(form.ID) != 4 {
+		t.Errorf("%d jobs, %d responses left; want nothing deleted", len(f.jobs.jobs), f.store.count(form.ID))
+	}
+}
+
+func TestBulkDeleteRefusesMoreMatches(t *testing.T) {
+	ctx := context.Background()
+	owner := uuid.New()
+	f := newResponseDeletionFixture(t, owner)
+	form := f.form(t, owner, 10)
+
+	after := f.clock.now().AddDate(0, 0, -3)
+	filter := models.ResponseFilter{SubmittedAfter: &after}
+	preview, err := f.svc.DryRun(ctx, form.ID, owner, filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.store.submit(form.ID, 1, f.clock.now(), false)
+	if _, err := f.svc.Start(ctx, form.ID, owner, BulkDeleteRequest{ResponseFilter: filter, ConfirmationToken: preview.ConfirmationToken}); !errors.Is(err, ErrMatchesChanged) {
+		t.Errorf("start after a response matched: err = %v, want ErrMatchesChanged", err)
+	}
+}
+
+func TestBulkDeleteCap(t *testing.T) {
+	ctx := context.Background()
+	owner, member := uuid.New(), uuid.New()
+	f := newResponseDeletionFixture(t, owner)
+	if _, err := f.orgs.AddMember(ctx, &models.OrganizationMember{OrganizationID: f.org.ID, UserID: member, Role: models.OrganizationRoleMember}); err != nil {
+		t.Fatal(err)
+	}
+
+	before := f.clock.now()
+	everything := BulkDeleteRequest{ResponseFilter: models.ResponseFilter{SubmittedBefore: &before}}
+	override := everything
+	override.OverrideCap = true
+
+	memberForm := f.form(t, member, 4)
+	if _, err := f.confirm(t, memberForm.ID, member, everything); !errors.Is(err, ErrDeletionCapExceeded) {
+		t.Errorf("deleting every response: err = %v, want ErrDeletionCapExceeded", err)
+	}
+	if _, err := f.confirm(t, memberForm.ID, member, override); !errors.Is(err, ErrInsufficientOrganizationRole) {
+		t.Errorf("override by a member: err = %v, want ErrInsufficientOrganizationRole", err)
+	}
+
+	ownerForm := f.form(t, owner, 4)
+	job, err := f.confirm(t, ownerForm.ID, owner, override)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !job.CapOverridden || job.Matched != 4 {
+		t.Errorf("job = %+v, want the cap overridden for 4 responses", job)
+	}
+
+	// Test submissions are deleted without a cap, and not released
+	f.store.submit(memberForm.ID, 3, f.clock.now(), true)
+	counted, _ := f.responsesUsed(t)
+	if _, err := f.confirm(t, memberForm.ID, member, BulkDeleteRequest{ResponseFilter: models.ResponseFilter{TestOnly: true}}); err != nil {
+		t.Fatal(err)
+	}
+	f.run(t)
+	if f.store.count(memberForm.ID) != 4 || f.store.count(ownerForm.ID) != 0 {
+		t.Errorf("%d and %d responses left, want 4 and 0", f.store.count(memberForm.ID), f.store.count(ownerForm.ID))
+	}
+	if after, _ := f.responsesUsed(t); after != counted-4 {
+		t.Errorf("%d responses counted after the deletions, want %d", after, counted-4)
+	}
+}
+
+func TestBulkDeleteResumesAfterFailure(t *testing.T) {
+	ctx := context.Background()
+	owner := uuid.New()
+	f := newResponseDeletionFixture(t, owner)
+	form := f.form(t, owner, 10)
+	f.store.failDelete = func(call int) error {
+		if call == 2 {
+			return errors.New("response service unavailable")
+		}
+		return nil
+	}
+
+	ids := make([]string, 10)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("resp-%d", i+1)
+	}
+	job, err := f.confirm(t, form.ID, owner, BulkDeleteRequest{ResponseFilter: models.ResponseFilter{ResponseIDs: ids}, OverrideCap: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.run(t)
+
+	failed, _ := f.svc.GetJob(ctx, form.ID, job.ID, owner)
+	if failed.Status != models.ResponseDeletionFailed || failed.Deleted != 4 || len(failed.Pending.Data()) != 4 || failed.Error == "" {
+		t.Fatalf("job %s with %d deleted and %d pending, want failed on the second batch of 4", failed.Status, failed.Deleted, len(failed.Pending.Data()))
+	}
+	if f.store.count(form.ID) != 6 {
+		t.Errorf("%d responses left, want 6", f.store.count(form.ID))
+	}
+	if _, err := f.svc.Resume(ctx, form.ID, job.ID, uuid.New()); !errors.Is(err, ErrNotFormOwner) {
+		t.Errorf("resume by a stranger: err = %v, want ErrNotFormOwner", err)
+	}
+
+	resumed, err := f.svc.Resume(ctx, form.ID, job.ID, owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumed.Status != models.ResponseDeletionQueued || resumed.Error != "" {
+		t.Errorf("resumed job = %+v, want queued", resumed)
+	}
+	f.run(t)
+
+	done, _ := f.svc.GetJob(ctx, form.ID, job.ID, owner)
+	if done.Status != models.ResponseDeletionCompleted || done.Deleted != 10 || f.store.count(form.ID) != 0 {
+		t.Errorf("job %s with %d deleted, %d responses left; want all 10 deleted", done.Status, done.Deleted, f.store.count(form.ID))
+	}
+	deleted := f.deletedIDs()
+	sort.Strings(deleted)
+	sort.Strings(ids)
+	if fmt.Sprint(deleted) != fmt.Sprint(ids) {
+		t.Errorf("form.responses.deleted for %v, want each response once", deleted)
+	}
+	if counted, flushed := f.responsesUsed(t); counted != 0 || flushed != 0 {
+		t.Errorf("%d responses counted and %d flushed, want every response released once", counted, flushed)
+	}
+	if _, err := f.svc.Resume(ctx, form.ID, job.ID, owner); !errors.Is(err, ErrResponseDeletionNotFailed) {
+		t.Errorf("resume of a completed job: err = %v, want ErrResponseDeletionNotFailed", err)
+	}
+}
+
+func TestPurgeTestResponses(t *testing.T) {
+	ctx := context.Background()
+	owner := uuid.New()
+	f := newResponseDeletionFixture(t, owner)
+	form := f.form(t, owner, 5)
+	if _, err := f.svc.PurgeTest(ctx, form.ID, owner); !errors.Is(err, ErrResponseDeletionInvalid) {
+		t.Errorf("purge without test responses: err = %v, want ErrResponseDeletionInvalid", err)
+	}
+
+	f.store.submit(form.ID, 3, f.clock.now(), true)
+	counted, _ := f.responsesUsed(t)
+	if _, err := f.svc.PurgeTest(ctx, form.ID, uuid.New()); !errors.Is(err, ErrNotFormOwner) {
+		t.Errorf("purge by a stranger: err = %v, want ErrNotFormOwner", err)
+	}
+
+	// No dry run is needed
+	job, err := f.svc.PurgeTest(ctx, form.ID, owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Matched != 3 || job.Total != 8 || !job.Filter.Data().TestOnly {
+		t.Errorf("job = %+v, want the 3 test responses of 8", job)
+	}
+	f.run(t)
+	job, _ = f.svc.GetJob(ctx, form.ID, job.ID, owner)
+	if job.Status != models.ResponseDeletionCompleted || f.store.count(form.ID) != 5 {
+		t.Errorf("job %s, %d responses left; want completed and the 5 real ones left", job.Status, f.store.count(form.ID))
+	}
+	if after, _ := f.responsesUsed(t); after != counted {
+		t.Errorf("%d responses counted after the purge, want %d", after, counted)
+	}
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/analytics"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+)
+
+// ResultsService defines the interface for publishing the results of forms.
+// Only the answer distributions of the questions editors made
+// aggregate_public are published, and only once enough people answered them
+// that no answer can be told apart.
+type ResultsService interface {
+	GetPublicResults(ctx context.Context, formID uuid.UUID) (*PublicResults, error)
+	CachePurger
+}
+
+// CachePurger is a cache admins clear through the gateway
+type CachePurger interface {
+	// PurgeCache drops the entries of the forms whose ID matches pattern,
+	// where * matches any run of characters and ? any one, and returns the
+	// number of entries dropped
+	PurgeCache(ctx context.Context, pattern string) (int, error)
+}
+
+// ErrInvalidCachePattern is returned when the pattern of a cache purge is
+// malformed
+var ErrInvalidCachePattern = errors.New("invalid cache pattern")
+
+// ResultsConfig configures the public results of forms
+type ResultsConfig struct {
+	// CacheTTL is how long the distribution of a question is served before
+	// it is read again from the analytics service
+	CacheTTL time.Duration
+	// MinResponses is the k-anonymity threshold: the distribution of a
+	// question with fewer responses is withheld
+	MinResponses int
+}
+
+// PublicResults are the public results of a published form
+type PublicResults struct {
+	FormID uuid.UUID `json:"form_id"`
+	// MinResponses is the number of responses a question needs for its
+	// results to be published
+	MinResponses int                     `json:"min_responses" example:"5"`
+	Questions    []PublicQuestionResults `json:"questions"`
+}
+
+// PublicQuestionResults is the answer distribution of a question. Responses
+// and Distribution are null while the question has fewer responses than
+// the threshold.
+type PublicQuestionResults struct {
+	QuestionID uuid.UUID           `json:"question_id"`
+	Title      string              `json:"title"`
+	Type       models.QuestionType `json:"type"`
+	Responses  *int                `json:"responses"`
+	// Distribution is the number of responses choosing each answer
+	Distribution map[string]int `json:"distribution"`
+}
+
+// cachedDistribution is the distribution of a question read at some point
+type cachedDistribution struct {
+	formID       uuid.UUID
+	distribution *analytics.Distribution
+	expiresAt    time.Time
+}
+
+// resultsService implements ResultsService interface
+type resultsService struct {
+	formRepo     repository.FormRepository
+	questionRepo repository.QuestionRepository
+	distributor  analytics.Distributor
+	config       ResultsConfig
+	now          func() time.Time
+
+	mu    sync.Mutex
+	cache map[uuid.UUID]cachedDistribution
+}
+
+// NewResultsService creates a new results service instance reading the
+// answer distributions of questions from distributor
+func NewResultsService(formRepo repository.FormRepository, questionRepo repository.QuestionRepository, distributor analytics.Distributor, config ResultsConfig) ResultsService {
+	return &resultsService{
+		formRepo:     formRepo,
+		questionRepo: questionRepo,
+		distributor:  distributor,
+		config:       config,
+		now:          time.Now,
+		cache:        make(map[uuid.UUID]cachedDistribution),
+	}
+}
+
+// GetPublicResults returns the public results of a form. Forms that are not
+// published are not found. The form and its questions are read on every
+// call, so that a question made private is withdrawn at once; only the
+// distributions are cached.
+func (s *resultsService) GetPublicResults(ctx context.Context, formID uuid.UUID) (*PublicResults, error) {
+	form, err := s.formRepo.GetByID(ctx, formID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrFormNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get form: %w", err)
+	}
+	if form.Status != models.FormStatusPublished {
+		return nil, ErrFormNotFound
+	}
+
+	questions, err := s.questionRepo.GetByFormID(ctx, form.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get questions: %w", err)
+	}
+
+	results := &PublicResults{
+		FormID:       form.ID,
+		MinResponses: s.config.MinResponses,
+		Questions:    []PublicQuestionResults{},
+	}
+	for _, question := range questions {
+		// The type is checked again for questions whose visibility was set
+		// before they changed type outside the service
+		if question.ResultsVisibility != models.ResultsVisibilityAggregatePublic || !question.Type.Aggregatable() {
+			continue
+		}
+		distribution, err := s.distribution(ctx, form.ID, question)
+		if err != nil {
+			return nil, err
+		}
+
+		result := PublicQuestionResults{
+			QuestionID: question.ID,
+			Title:      question.Title,
+			Type:       question.Type,
+		}
+		if distribution.TotalResponses >= s.config.MinResponses {
+			responses := distribution.TotalResponses
+			result.Responses = &responses
+			result.Distribution = distribution.Answers
+			if result.Distribution == nil {
+				result.Distribution = map[string]int{}
+			}
+		}
+		results.Questions = append(results.Questions, result)
+	}
+	return results, nil
+}
+
+// distribution returns the answer distribution of a question, from the
+// cache while it is fresh
+func (s *resultsService) distribution(ctx context.Context, formID uuid.UUID, question *models.Question) (*analytics.Distribution, error) {
+	now := s.now()
+	s.mu.Lock()
+	cached, ok := s.cache[question.ID]
+	s.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.distribution, nil
+	}
+
+	distribution, err := s.distributor.QuestionDistribution(ctx, formID.String(), question.ID.String(), analyticsQuestionType(question.Type))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get results of question %s: %w", question.ID, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, entry := range s.cache {
+		if !now.Before(entry.expiresAt) {
+			delete(s.cache, id)
+		}
+	}
+	s.cache[question.ID] = cachedDistribution{formID: formID, distribution: distribution, expiresAt: now.Add(s.config.CacheTTL)}
+	return distribution, nil
+}
+
+// PurgeCache drops the cached distributions of the questions of the forms
+// matching pattern, so they are read again from the analytics service
+func (s *resultsService) PurgeCache(ctx context.Context, pattern string) (int, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidCachePattern, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	purged := 0
+	for id, entry := range s.cache {
+		if matched, _ := path.Match(pattern, entry.formID.String()); matched {
+			delete(s.cache, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// analyticsQuestionType is the question type the analytics service knows a
+// question type by
+func analyticsQuestionType(t models.QuestionType) string {
+	if t == models.QuestionTypeSelect {
+		return "multiple_choice"
+	}
+	return string(t)
+}
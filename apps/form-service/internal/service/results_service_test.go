@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/analytics"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+)
+
+// countingDistributor returns the distributions of questions, counting the
+// reads
+type countingDistributor struct {
+	distributions map[string]*analytics.Distribution
+	reads         int
+}
+
+func (d *countingDistributor) QuestionDistribution(_ context.Context, _, questionID, _ string) (*analytics.Distribution, error) {
+	d.reads++
+	if distribution, ok := d.distributions[questionID]; ok {
+		return distribution, nil
+	}
+	return &analytics.Distribution{QuestionID: questionID}, nil
+}
+
+func TestPublicResults(t *testing.T) {
+	ctx := context.Background()
+	repos := newMemoryStore(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	owner := uuid.New()
+	form := repos.createForm(t, &models.Form{UserID: owner, Title: "Poll", Status: models.FormStatusDraft})
+
+	formSvc := repos.formService()
+	public := models.ResultsVisibilityAggregatePublic
+	if _, err := formSvc.AddQuestion(ctx, form.ID, owner, AddQuestionRequest{Type: models.QuestionTypeText, Title: "Why?", ResultsVisibility: public}); !errors.Is(err, ErrInvalidResultsVisibility) {
+		t.Errorf("public text question: err = %v, want ErrInvalidResultsVisibility", err)
+	}
+	popular, err := formSvc.AddQuestion(ctx, form.ID, owner, AddQuestionRequest{Type: models.QuestionTypeRadio, Title: "Favourite?", ResultsVisibility: public})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rare, err := formSvc.AddQuestion(ctx, form.ID, owner, AddQuestionRequest{Type: models.QuestionTypeCheckbox, Title: "Allergies?", ResultsVisibility: public})
+	if err != nil {
+		t.Fatal(err)
+	}
+	private, err := formSvc.AddQuestion(ctx, form.ID, owner, AddQuestionRequest{Type: models.QuestionTypeSelect, Title: "Department?"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if private.ResultsVisibility != models.ResultsVisibilityPrivate {
+		t.Errorf("results visibility defaults to %q, want private", private.ResultsVisibility)
+	}
+	text := models.QuestionTypeText
+	if _, err := formSvc.UpdateQuestion(ctx, popular.ID, owner, UpdateQuestionRequest{Type: &text}); !errors.Is(err, ErrInvalidResultsVisibility) {
+		t.Errorf("public question changed to text: err = %v, want ErrInvalidResultsVisibility", err)
+	}
+
+	distributor := &countingDistributor{distributions: map[string]*analytics.Distribution{
+		popular.ID.String(): {TotalResponses: 5, Answers: map[string]int{"tea": 3, "coffee": 2}},
+		rare.ID.String():    {TotalResponses: 4, Answers: map[string]int{"nuts": 1, "gluten": 3}},
+		private.ID.String(): {TotalResponses: 50, Answers: map[string]int{"sales": 50}},
+	}}
+	svc := NewResultsService(repos.forms, repos.questions, distributor, ResultsConfig{CacheTTL: time.Minute, MinResponses: 5}).(*resultsService)
+	svc.now = repos.clock.now
+
+	if _, err := svc.GetPublicResults(ctx, form.ID); !errors.Is(err, ErrFormNotFound) {
+		t.Fatalf("results of a draft: err = %v, want ErrFormNotFound", err)
+	}
+
+	form.Status = models.FormStatusPublished
+	if err := repos.forms.Update(ctx, form); err != nil {
+		t.Fatal(err)
+	}
+	results, err := svc.GetPublicResults(ctx, form.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[uuid.UUID]PublicQuestionResults)
+	for _, result := range results.Questions {
+		got[result.QuestionID] = result
+	}
+	if len(got) != 2 {
+		t.Fatalf("got results of %d questions, want the 2 public ones", len(got))
+	}
+	if result := got[popular.ID]; result.Responses == nil || *result.Responses != 5 || result.Distribution["tea"] != 3 {
+		t.Errorf("results at the threshold = %+v, want the distribution", result)
+	}
+	// Below the threshold nothing is published, not even the count
+	if result := got[rare.ID]; result.Responses != nil || result.Distribution != nil {
+		t.Errorf("results below the threshold = %+v, want them withheld", result)
+	}
+
+	// Distributions are cached for the TTL
+	if _, err := svc.GetPublicResults(ctx, form.ID); err != nil {
+		t.Fatal(err)
+	}
+	if distributor.reads != 2 {
+		t.Errorf("read %d distributions, want 2 within the TTL", distributor.reads)
+	}
+	distributor.distributions[rare.ID.String()] = &analytics.Distribution{TotalResponses: 6, Answers: map[string]int{"nuts": 2, "gluten": 4}}
+	repos.clock.advance(time.Minute)
+	results, err = svc.GetPublicResults(ctx, form.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range results.Questions {
+		if result.QuestionID == rare.ID && (result.Responses == nil || *result.Responses != 6) {
+			t.Errorf("results once past the threshold = %+v, want them published", result)
+		}
+	}
+	if distributor.reads != 4 {
+		t.Errorf("read %d distributions, want 4 after the TTL", distributor.reads)
+	}
+
+	// Purged distributions are read again before the TTL
+	if purged, err := svc.PurgeCache(ctx, "00000000-*"); err != nil || purged != 0 {
+		t.Errorf("purge of other forms = %d, %v, want nothing purged", purged, err)
+	}
+	if purged, err := svc.PurgeCache(ctx, form.ID.String()[:8]+"*"); err != nil || purged != 2 {
+		t.Errorf("purge of the form = %d, %v, want its 2 distributions purged", purged, err)
+	}
+	if _, err := svc.GetPublicResults(ctx, form.ID); err != nil {
+		t.Fatal(err)
+	}
+	if distributor.reads != 6 {
+		t.Errorf("read %d distributions, want 6 after the purge", distributor.reads)
+	}
+	if _, err := svc.PurgeCache(ctx, "[a-"); !errors.Is(err, ErrInvalidCachePattern) {
+		t.Errorf("purge with a malformed pattern: err = %v, want ErrInvalidCachePattern", err)
+	}
+}
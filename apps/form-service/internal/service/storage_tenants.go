@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+)
+
+const (
+	// storageTenantTTL bounds how long the organization of a form is
+	// cached, so the objects of a transferred form soon follow it
+	storageTenantTTL = time.Minute
+	// storageTenantCacheSize is how many forms are cached before the cache
+	// is emptied
+	storageTenantCacheSize = 10000
+)
+
+// StorageTenants tells the organization owning the objects stored for a
+// form from their keys, so the objects of organizations with storage of
+// their own are stored in their buckets
+type StorageTenants struct {
+	forms repository.FormOrganizations
+	now   func() time.Time
+
+	mu     sync.Mutex
+	cached map[uuid.UUID]cachedTenant
+}
+
+type cachedTenant struct {
+	orgID   uuid.UUID
+	expires time.Time
+}
+
+// NewStorageTenants creates a tenant lookup reading the organizations of
+// forms from forms
+func NewStorageTenants(forms repository.FormOrganizations) *StorageTenants {
+	return &StorageTenants{forms: forms, now: time.Now, cached: make(map[uuid.UUID]cachedTenant)}
+}
+
+// TenantOf returns the organization of the form the object at key is
+// stored for, or "" for the objects of no form, such as privacy exports.
+// It is the storage.TenantFunc of the storage router.
+func (t *StorageTenants) TenantOf(ctx context.Context, key string) (string, error) {
+	formID, ok := storageKeyForm(key)
+	if !ok {
+		return "", nil
+	}
+
+	now := t.now()
+	t.mu.Lock()
+	cached, ok := t.cached[formID]
+	t.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.orgID.String(), nil
+	}
+
+	orgID, err := t.forms.OrganizationOf(ctx, formID)
+	if err != nil {
+		return "", err
+	}
+	t.mu.Lock()
+	if len(t.cached) >= storageTenantCacheSize {
+		t.cached = make(map[uuid.UUID]cachedTenant)
+	}
+	t.cached[formID] = cachedTenant{orgID: orgID, expires: now.Add(storageTenantTTL)}
+	t.mu.Unlock()
+	return orgID.String(), nil
+}
+
+// storageKeyForm parses the form of the keys objects are stored at for
+// forms: uploads and quarantined uploads, exports, archives, receipts and
+// reports
+func storageKeyForm(key string) (uuid.UUID, bool) {
+	parts := strings.Split(strings.TrimPrefix(key, quarantinePrefix), "/")
+	var id string
+	switch {
+	case len(parts) >= 2 && (parts[0] == "uploads" || parts[0] == "receipts" || parts[0] == "reports"):
+		id = parts[1]
+	case len(parts) >= 3 && parts[0] == "exports" && parts[1] == "forms":
+		id = parts[2]
+	case len(parts) == 3 && parts[0] == "archives" && parts[1] == "forms":
+		id = strings.TrimSuffix(parts[2], ".json")
+	default:
+		return uuid.Nil, false
+	}
+	formID, err := uuid.Parse(id)
+	return formID, err == nil
+}
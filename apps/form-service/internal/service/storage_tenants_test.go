@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// stubFormOrganizations knows the organizations of forms, counting lookups
+type stubFormOrganizations struct {
+	orgs    map[uuid.UUID]uuid.UUID
+	lookups int
+}
+
+func (s *stubFormOrganizations) OrganizationOf(ctx context.Context, formID uuid.UUID) (uuid.UUID, error) {
+	s.lookups++
+	orgID, ok := s.orgs[formID]
+	if !ok {
+		return uuid.Nil, gorm.ErrRecordNotFound
+	}
+	return orgID, nil
+}
+
+func TestStorageTenantsParsesTheFormOfEveryKey(t *testing.T) {
+	formID, orgID := uuid.New(), uuid.New()
+	tenants := NewStorageTenants(&stubFormOrganizations{orgs: map[uuid.UUID]uuid.UUID{formID: orgID}})
+	ctx := context.Background()
+
+	for _, key := range []string{
+		"uploads/" + formID.String() + "/q/u/cv.pdf",
+		"quarantine/uploads/" + formID.String() + "/q/u/cv.pdf",
+		"exports/forms/" + formID.String() + "/job.csv",
+		"archives/forms/" + formID.String() + ".json",
+		"receipts/" + formID.String() + "/r1/r1.pdf",
+		"reports/" + formID.String() + "/20260101T000000Z.csv",
+	} {
+		tenant, err := tenants.TenantOf(ctx, key)
+		if err != nil || tenant != orgID.String() {
+			t.Errorf("TenantOf(%s) = %q, %v", key, tenant, err)
+		}
+	}
+	for _, key := range []string{"privacy/exports/r1.json", "uploads/", "exports/forms/not-a-form/job.csv"} {
+		if tenant, err := tenants.TenantOf(ctx, key); err != nil || tenant != "" {
+			t.Errorf("TenantOf(%s) = %q, %v, want no tenant", key, tenant, err)
+		}
+	}
+	if _, err := tenants.TenantOf(ctx, "uploads/"+uuid.NewString()+"/q/u/cv.pdf"); err == nil {
+		t.Error("the tenant of an unknown form was found")
+	}
+}
+
+func TestStorageTenantsCachesOrganizations(t *testing.T) {
+	formID := uuid.New()
+	forms := &stubFormOrganizations{orgs: map[uuid.UUID]uuid.UUID{formID: uuid.New()}}
+	tenants := NewStorageTenants(forms)
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	tenants.now = func() time.Time { return now }
+	key := "uploads/" + formID.String() + "/q/u/cv.pdf"
+
+	for i := 0; i < 3; i++ {
+		if _, err := tenants.TenantOf(context.Background(), key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if forms.lookups != 1 {
+		t.Fatalf("%d lookups, want 1", forms.lookups)
+	}
+
+	// A transferred form is stored in the bucket of its new organization
+	// once the cache expired
+	newOrg := uuid.New()
+	forms.orgs[formID] = newOrg
+	now = now.Add(2 * storageTenantTTL)
+	if tenant, err := tenants.TenantOf(context.Background(), key); err != nil || tenant != newOrg.String() {
+		t.Fatalf("tenant after the transfer = %q, %v", tenant, err)
+	}
+}
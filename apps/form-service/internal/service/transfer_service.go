@@ -0,0 +1,433 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+)
+
+var (
+	// ErrTransferNotFound is returned for transfers that don't exist and
+	// for those the user is no party to
+	ErrTransferNotFound = errors.New("transfer not found")
+	// ErrInvalidTransfer is returned for transfers that can't be requested
+	ErrInvalidTransfer = errors.New("invalid transfer")
+	// ErrTransferPending is returned when the form already has a pending
+	// transfer
+	ErrTransferPending = errors.New("form already has a pending transfer; cancel it first")
+	// ErrTransferNotPending is returned for transfers already accepted or
+	// cancelled, including by a concurrent request
+	ErrTransferNotPending = errors.New("transfer is no longer pending")
+	// ErrTransferExpired is returned for transfers not accepted in time
+	ErrTransferExpired = errors.New("transfer expired")
+)
+
+// TransferService defines the interface for the transfer of forms between
+// owners and organizations. The owner of a form, or an owner or admin of
+// its organization, requests a transfer; the target accepts it within
+// models.TransferTTL. Either side may cancel it while it is pending.
+type TransferService interface {
+	RequestTransfer(ctx context.Context, formID, userID uuid.UUID, req TransferFormRequest) (*models.FormTransfer, error)
+	ListTransfers(ctx context.Context, userID uuid.UUID, status models.TransferStatus) ([]*models.FormTransfer, error)
+	AcceptTransfer(ctx context.Context, transferID, userID uuid.UUID) (*models.FormTransfer, error)
+	CancelTransfer(ctx context.Context, transferID, userID uuid.UUID) (*models.FormTransfer, error)
+}
+
+// TransferFormRequest names the user or the organization a form is
+// transferred to; exactly one is required
+type TransferFormRequest struct {
+	ToUserID         *uuid.UUID `json:"to_user_id,omitempty" swaggertype:"string" format:"uuid" example:"5f0c6a3e-8a44-4c1f-9d43-2b6f3f3f8c11"`
+	ToOrganizationID *uuid.UUID `json:"to_organization_id,omitempty" swaggertype:"string" format:"uuid"`
+}
+
+// transferService implements TransferService interface
+type transferService struct {
+	transfers   repository.TransferRepository
+	orgs        repository.OrganizationRepository
+	guard       formGuard
+	publisher   events.Publisher
+	auditor     events.Auditor
+	usage       *UsageMeter
+	definitions *DefinitionCache
+	now         func() time.Time
+}
+
+// NewTransferService creates a new transfer service instance. Transfers
+// are recorded to auditor, and forms moved are published for the caches of
+// the gateway. Organizations receiving a form are held to the active forms
+// quota of their plan by usage, which may be nil. The forms moved are
+// dropped from definitions, which may be nil too.
+func NewTransferService(transfers repository.TransferRepository, formRepo repository.FormRepository, collaboratorRepo repository.CollaboratorRepository, orgRepo repository.OrganizationRepository, publisher events.Publisher, auditor events.Auditor, usage *UsageMeter, definitions *DefinitionCache) TransferService {
+	return &transferService{
+		transfers:   transfers,
+		orgs:        orgRepo,
+		guard:       formGuard{forms: formRepo, collaborators: collaboratorRepo, orgs: organizationScope{orgs: orgRepo}},
+		publisher:   publisher,
+		auditor:     auditor,
+		usage:       usage,
+		definitions: definitions,
+		now:         time.Now,
+	}
+}
+
+// RequestTransfer records a pending transfer of the form. A form of a team
+// organization goes to a member of it, or to another organization; a form
+// of a personal organization goes to the personal organization of the user
+// it is transferred to.
+func (s *transferService) RequestTransfer(ctx context.Context, formID, userID uuid.UUID, req TransferFormRequest) (*models.FormTransfer, error) {
+	if (req.ToUserID == nil) == (req.ToOrganizationID == nil) {
+		return nil, fmt.Errorf("%w: either to_user_id or to_organization_id is required", ErrInvalidTransfer)
+	}
+
+	form, err := s.guard.getForm(ctx, formID, userID)
+	if err != nil {
+		return nil, err
+	}
+	from, err := s.orgs.GetByID(ctx, form.OrganizationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	if err := s.authorizeSource(ctx, form, from, userID); err != nil {
+		return nil, err
+	}
+
+	if req.ToUserID != nil {
+		if *req.ToUserID == form.UserID {
+			return nil, fmt.Errorf("%w: the user already owns the form", ErrInvalidTransfer)
+		}
+		if !from.Personal {
+			if _, err := s.orgs.GetMember(ctx, from.ID, *req.ToUserID); errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("%w: the user is not a member of the organization of the form; transfer it to their organization instead", ErrInvalidTransfer)
+			} else if err != nil {
+				return nil, fmt.Errorf("failed to get member: %w", err)
+			}
+		}
+	} else {
+		to, err := s.orgs.GetByID(ctx, *req.ToOrganizationID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: organization %s not found", ErrInvalidTransfer, *req.ToOrganizationID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get organization: %w", err)
+		}
+		if to.ID == form.OrganizationID {
+			return nil, fmt.Errorf("%w: the form already belongs to the organization", ErrInvalidTransfer)
+		}
+		if to.Personal {
+			return nil, fmt.Errorf("%w: transfer the form to the user of a personal organization instead", ErrInvalidTransfer)
+		}
+		if err := s.checkQuota(ctx, form, to.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Transfers not accepted in time no longer hold up new ones
+	if _, err := s.transfers.ExpirePending(ctx, s.now()); err != nil {
+		return nil, fmt.Errorf("failed to expire transfers: %w", err)
+	}
+	now := s.now()
+	transfer := &models.FormTransfer{
+		ID:                 uuid.New(),
+		FormID:             form.ID,
+		FormTitle:          form.Title,
+		FromUserID:         form.UserID,
+		FromOrganizationID: form.OrganizationID,
+		ToUserID:           req.ToUserID,
+		ToOrganizationID:   req.ToOrganizationID,
+		Status:             models.TransferStatusPending,
+		RequestedBy:        userID,
+		ExpiresAt:          now.Add(models.TransferTTL),
+	}
+	err = s.transfers.Create(ctx, transfer)
+	if errors.Is(err, repository.ErrTransferPending) {
+		return nil, ErrTransferPending
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transfer: %w", err)
+	}
+
+	s.record(ctx, events.AuditTransferRequested, userID, transfer, nil)
+	return transfer, nil
+}
+
+// ListTransfers lists the transfers from and to the user, and from and to
+// the organizations they may transfer the forms of
+func (s *transferService) ListTransfers(ctx context.Context, userID uuid.UUID, status models.TransferStatus) ([]*models.FormTransfer, error) {
+	if status != "" && !status.IsValid() {
+		return nil, fmt.Errorf("%w: invalid status: %s", ErrInvalidTransfer, status)
+	}
+	if _, err := s.transfers.ExpirePending(ctx, s.now()); err != nil {
+		return nil, fmt.Errorf("failed to expire transfers: %w", err)
+	}
+
+	memberships, err := s.orgs.ListMemberships(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list memberships: %w", err)
+	}
+	var orgIDs []uuid.UUID
+	for _, member := range memberships {
+		if member.Role.CanTransferForms() {
+			orgIDs = append(orgIDs, member.OrganizationID)
+		}
+	}
+
+	transfers, err := s.transfers.ListFor(ctx, userID, orgIDs, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transfers: %w", err)
+	}
+	return transfers, nil
+}
+
+// AcceptTransfer moves the form of a pending transfer to the user accepting
+// it, in the organization of the transfer, or else in the organization of
+// the form, or else in their personal organization
+func (s *transferService) AcceptTransfer(ctx context.Context, transferID, userID uuid.UUID) (*models.FormTransfer, error) {
+	transfer, err := s.pending(ctx, transferID, userID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.isTarget(ctx, transfer, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !target {
+		return nil, ErrInsufficientOrganizationRole
+	}
+
+	from, err := s.orgs.GetByID(ctx, transfer.FromOrganizationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	destination := repository.TransferDestination{OwnerID: userID, OrganizationID: from.ID}
+	switch {
+	case transfer.ToOrganizationID != nil:
+		destination.OrganizationID = *transfer.ToOrganizationID
+	case from.Personal:
+		personal, err := s.orgs.Personal(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get personal organization: %w", err)
+		}
+		destination.OrganizationID = personal.ID
+		destination.KeepCollaboratorsOutside = true
+	}
+
+	form, err := s.guard.forms.GetByID(ctx, transfer.FormID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrFormNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get form: %w", err)
+	}
+	if destination.OrganizationID != form.OrganizationID {
+		if err := s.checkQuota(ctx, form, destination.OrganizationID); err != nil {
+			return nil, err
+		}
+	}
+
+	moved, err := s.transfers.Accept(ctx, transfer, destination, userID, s.now())
+	switch {
+	case errors.Is(err, repository.ErrTransferNotPending):
+		return nil, s.notPending(ctx, transferID)
+	case errors.Is(err, repository.ErrFormMoved):
+		return nil, fmt.Errorf("%w: %v", ErrTransferNotPending, err)
+	case errors.Is(err, repository.ErrSlugTaken):
+		return nil, ErrSlugTaken
+	case err != nil:
+		return nil, fmt.Errorf("failed to accept transfer: %w", err)
+	}
+
+	s.definitions.invalidate(ctx, moved.Form.ID)
+	s.record(ctx, events.AuditFormTransferred, userID, transfer, moved)
+	s.publishTransferred(ctx, transfer, moved)
+	return transfer, nil
+}
+
+// CancelTransfer cancels a pending transfer, for the source as for the
+// target, which declines it
+func (s *transferService) CancelTransfer(ctx context.Context, transferID, userID uuid.UUID) (*models.FormTransfer, error) {
+	transfer, err := s.pending(ctx, transferID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.transfers.Cancel(ctx, transfer, userID, s.now())
+	if errors.Is(err, repository.ErrTransferNotPending) {
+		return nil, s.notPending(ctx, transferID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel transfer: %w", err)
+	}
+
+	s.record(ctx, events.AuditTransferCancelled, userID, transfer, nil)
+	return transfer, nil
+}
+
+// pending gets a transfer the user is a party to, while it is pending
+func (s *transferService) pending(ctx context.Context, transferID, userID uuid.UUID) (*models.FormTransfer, error) {
+	transfer, err := s.transfers.GetByID(ctx, transferID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTransferNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transfer: %w", err)
+	}
+
+	source, err := s.isSource(ctx, transfer, userID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.isTarget(ctx, transfer, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !source && !target {
+		return nil, ErrTransferNotFound
+	}
+
+	if transfer.Expired(s.now()) {
+		if _, err := s.transfers.ExpirePending(ctx, s.now()); err != nil {
+			return nil, fmt.Errorf("failed to expire transfers: %w", err)
+		}
+		return nil, ErrTransferExpired
+	}
+	if transfer.Status == models.TransferStatusExpired {
+		return nil, ErrTransferExpired
+	}
+	if transfer.Status != models.TransferStatusPending {
+		return nil, fmt.Errorf("%w: it was %s", ErrTransferNotPending, transfer.Status)
+	}
+	return transfer, nil
+}
+
+// notPending tells why a transfer found pending no longer was when it was
+// resolved: a concurrent request resolved it, or it expired
+func (s *transferService) notPending(ctx context.Context, transferID uuid.UUID) error {
+	transfer, err := s.transfers.GetByID(ctx, transferID)
+	if err != nil {
+		return ErrTransferNotPending
+	}
+	if transfer.Status == models.TransferStatusExpired || transfer.Expired(s.now()) {
+		return ErrTransferExpired
+	}
+	return fmt.Errorf("%w: it was %s", ErrTransferNotPending, transfer.Status)
+}
+
+// authorizeSource checks the user may transfer the form: its owner, or an
+// owner or admin of its team organization
+func (s *transferService) authorizeSource(ctx context.Context, form *models.Form, org *models.Organization, userID uuid.UUID) error {
+	if form.UserID == userID {
+		return nil
+	}
+	if !org.Personal {
+		member, err := s.orgs.GetMember(ctx, org.ID, userID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to get member: %w", err)
+		}
+		if member != nil && member.Role.CanTransferForms() {
+			return nil
+		}
+	}
+	return ErrNotFormOwner
+}
+
+// isSource reports whether the user may cancel the transfer for the side of
+// the form: whoever requested it, the owner of the form, or an owner or
+// admin of its organization
+func (s *transferService) isSource(ctx context.Context, transfer *models.FormTransfer, userID uuid.UUID) (bool, error) {
+	if transfer.RequestedBy == userID || transfer.FromUserID == userID {
+		return true, nil
+	}
+	return s.canTransferIn(ctx, transfer.FromOrganizationID, userID)
+}
+
+// isTarget reports whether the user may accept the transfer: the user it
+// is to, or an owner or admin of the organization it is to
+func (s *transferService) isTarget(ctx context.Context, transfer *models.FormTransfer, userID uuid.UUID) (bool, error) {
+	if transfer.ToUserID != nil {
+		return *transfer.ToUserID == userID, nil
+	}
+	return s.canTransferIn(ctx, *transfer.ToOrganizationID, userID)
+}
+
+// canTransferIn reports whether the user is an owner or admin of the
+// organization
+func (s *transferService) canTransferIn(ctx context.Context, orgID, userID uuid.UUID) (bool, error) {
+	member, err := s.orgs.GetMember(ctx, orgID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get member: %w", err)
+	}
+	return member.Role.CanTransferForms(), nil
+}
+
+// checkQuota returns ErrQuotaExceeded when an active form would take the
+// organization over the active forms quota of its plan
+func (s *transferService) checkQuota(ctx context.Context, form *models.Form, orgID uuid.UUID) error {
+	if form.Status == models.FormStatusClosed {
+		return nil
+	}
+	return s.usage.checkActiveForms(ctx, orgID)
+}
+
+// publishTransferred tells the caches of the form that it moved, with the
+// slug it was resolved by before in the organization it was in
+func (s *transferService) publishTransferred(ctx context.Context, transfer *models.FormTransfer, moved *repository.TransferredForm) {
+	form := moved.Form
+	data := map[string]interface{}{
+		"form_id":                  form.ID.String(),
+		"transfer_id":              transfer.ID.String(),
+		"organization_id":          form.OrganizationID.String(),
+		"previous_organization_id": transfer.FromOrganizationID.String(),
+		"owner_id":                 form.UserID.String(),
+		"previous_owner_id":        transfer.FromUserID.String(),
+		"slug":                     slugOf(form),
+		"changed_at":               s.now().UTC(),
+	}
+	if moved.PreviousSlug != slugOf(form) || form.OrganizationID != transfer.FromOrganizationID {
+		data["previous_slug"] = moved.PreviousSlug
+	}
+	if err := s.publisher.Publish(ctx, events.FormTransferred, form.ID.String(), data); err != nil {
+		log.Printf("Failed to publish %s event for form %s: %v", events.FormTransferred, form.ID, err)
+	}
+}
+
+// record records an audit event of the transfer, with the form as moved
+// once accepted
+func (s *transferService) record(ctx context.Context, action string, userID uuid.UUID, transfer *models.FormTransfer, moved *repository.TransferredForm) {
+	event := events.AuditEvent{
+		Action:       action,
+		Actor:        userID.String(),
+		ResourceType: "form",
+		ResourceID:   transfer.FormID.String(),
+		Before: map[string]interface{}{
+			"owner_id":        transfer.FromUserID.String(),
+			"organization_id": transfer.FromOrganizationID.String(),
+		},
+		After: map[string]interface{}{
+			"transfer_id": transfer.ID.String(),
+			"status":      transfer.Status,
+		},
+	}
+	if moved != nil {
+		event.After = map[string]interface{}{
+			"transfer_id":           transfer.ID.String(),
+			"status":                transfer.Status,
+			"owner_id":              moved.Form.UserID.String(),
+			"organization_id":       moved.Form.OrganizationID.String(),
+			"slug":                  slugOf(moved.Form),
+			"collaborators_removed": moved.CollaboratorsRemoved,
+		}
+	}
+	s.auditor.Record(ctx, event)
+}
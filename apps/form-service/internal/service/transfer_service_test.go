@@ -0,0 +1,416 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+)
+
+// memoryTransferRepository keeps transfers in memory and moves the forms of
+// those accepted in a memoryFormRepository
+type memoryTransferRepository struct {
+	mu        sync.Mutex
+	transfers map[uuid.UUID]models.FormTransfer
+	forms     *memoryFormRepository
+}
+
+func newMemoryTransferRepository(forms *memoryFormRepository) *memoryTransferRepository {
+	return &memoryTransferRepository{transfers: make(map[uuid.UUID]models.FormTransfer), forms: forms}
+}
+
+func (r *memoryTransferRepository) Create(_ context.Context, transfer *models.FormTransfer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.transfers {
+		if existing.FormID == transfer.FormID && existing.Status == models.TransferStatusPending {
+			return repository.ErrTransferPending
+		}
+	}
+	transfer.CreatedAt, transfer.UpdatedAt = r.forms.now(), r.forms.now()
+	r.transfers[transfer.ID] = *transfer
+	return nil
+}
+
+func (r *memoryTransferRepository) GetByID(_ context.Context, id uuid.UUID) (*models.FormTransfer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	transfer, ok := r.transfers[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &transfer, nil
+}
+
+func (r *memoryTransferRepository) ListFor(_ context.Context, userID uuid.UUID, orgIDs []uuid.UUID, status models.TransferStatus) ([]*models.FormTransfer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	inOrgs := func(id *uuid.UUID) bool {
+		for _, orgID := range orgIDs {
+			if id != nil && *id == orgID {
+				return true
+			}
+		}
+		return false
+	}
+	var transfers []*models.FormTransfer
+	for _, transfer := range r.transfers {
+		party := transfer.FromUserID == userID || transfer.RequestedBy == userID ||
+			(transfer.ToUserID != nil && *transfer.ToUserID == userID) ||
+			inOrgs(&transfer.FromOrganizationID) || inOrgs(transfer.ToOrganizationID)
+		if party && (status == "" || transfer.Status == status) {
+			transfer := transfer
+			transfers = append(transfers, &transfer)
+		}
+	}
+	sort.Slice(transfers, func(i, j int) bool { return transfers[i].CreatedAt.After(transfers[j].CreatedAt) })
+	return transfers, nil
+}
+
+func (r *memoryTransferRepository) Cancel(_ context.Context, transfer *models.FormTransfer, by uuid.UUID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := r.transfers[transfer.ID]
+	if stored.Status != models.TransferStatusPending {
+		return repository.ErrTransferNotPending
+	}
+	stored.Status, stored.ResolvedBy, stored.ResolvedAt = models.TransferStatusCancelled, &by, &at
+	r.transfers[transfer.ID] = stored
+	*transfer = stored
+	return nil
+}
+
+func (r *memoryTransferRepository) Accept(_ context.Context, transfer *models.FormTransfer, to repository.TransferDestination, by uuid.UUID, at time.Time) (*repository.TransferredForm, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := r.transfers[transfer.ID]
+	if stored.Status != models.TransferStatusPending || !at.Before(stored.ExpiresAt) {
+		return nil, repository.ErrTransferNotPending
+	}
+
+	r.forms.mu.Lock()
+	defer r.forms.mu.Unlock()
+	form, ok := r.forms.forms[stored.FormID]
+	if !ok || form.UserID != stored.FromUserID || form.OrganizationID != stored.FromOrganizationID {
+		return nil, repository.ErrFormMoved
+	}
+	form.UserID, form.OrganizationID, form.UpdatedAt = to.OwnerID, to.OrganizationID, at
+	r.forms.forms[form.ID] = form
+
+	stored.Status, stored.ResolvedBy, stored.ResolvedAt = models.TransferStatusAccepted, &by, &at
+	r.transfers[transfer.ID] = stored
+	*transfer = stored
+	return &repository.TransferredForm{Form: &form, PreviousSlug: slugOf(&form)}, nil
+}
+
+func (r *memoryTransferRepository) ExpirePending(_ context.Context, now time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var expired int64
+	for id, transfer := range r.transfers {
+		if transfer.Expired(now) {
+			transfer.Status = models.TransferStatusExpired
+			r.transfers[id] = transfer
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+type transferFixture struct {
+	*memoryStore
+	transfers *memoryTransferRepository
+	publisher *recordingPublisher
+	auditor   *recordingAuditor
+	svc       *transferService
+}
+
+// newTransferFixture creates a transfer service whose organizations may
+// have 2 active forms
+func newTransferFixture(t *testing.T) *transferFixture {
+	t.Helper()
+	f := &transferFixture{memoryStore: newMemoryStore(time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))}
+	f.transfers = newMemoryTransferRepository(f.forms)
+	f.publisher = &recordingPublisher{}
+	f.auditor = &recordingAuditor{}
+	meter := NewUsageMeter(newMemoryUsageCounter(), newMemoryUsageRepository(f.forms), f.forms, f.orgs, map[string]models.Plan{
+		models.DefaultPlan: {MaxActiveForms: 2},
+	}, true)
+	meter.now = f.clock.now
+	f.svc = NewTransferService(f.transfers, f.forms, nil, f.orgs, f.publisher, f.auditor, meter, nil).(*transferService)
+	f.svc.now = f.clock.now
+	return f
+}
+
+// form creates a published form of the owner in the organization, or in
+// their personal one
+func (f *transferFixture) form(t *testing.T, owner uuid.UUID, org *models.Organization) *models.Form {
+	t.Helper()
+	ctx := context.Background()
+	if org == nil {
+		personal, err := f.orgs.Personal(ctx, owner)
+		if err != nil {
+			t.Fatal(err)
+		}
+		org = personal
+	}
+	return f.createForm(t, &models.Form{UserID: owner, OrganizationID: org.ID, Title: "Survey", Status: models.FormStatusPublished})
+}
+
+func TestTransferToOrganization(t *testing.T) {
+	ctx := context.Background()
+	f := newTransferFixture(t)
+	alice, bob, carol, stranger := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	acme := f.createOrganization(t, bob, map[uuid.UUID]models.OrganizationRole{carol: models.OrganizationRoleMember})
+	form := f.form(t, alice, nil)
+
+	to := TransferFormRequest{ToOrganizationID: &acme.ID}
+	if _, err := f.svc.RequestTransfer(ctx, form.ID, stranger, to); !errors.Is(err, ErrNotFormOwner) {
+		t.Errorf("stranger requests: err = %v, want ErrNotFormOwner", err)
+	}
+	for name, req := range map[string]TransferFormRequest{
+		"no target":             {},
+		"both targets":          {ToUserID: &bob, ToOrganizationID: &acme.ID},
+		"the owner":             {ToUserID: &alice},
+		"its own organization":  {ToOrganizationID: &form.OrganizationID},
+		"unknown organization":  {ToOrganizationID: &stranger},
+		"personal organization": {ToOrganizationID: func() *uuid.UUID { p, _ := f.orgs.Personal(ctx, bob); return &p.ID }()},
+	} {
+		if _, err := f.svc.RequestTransfer(ctx, form.ID, alice, req); !errors.Is(err, ErrInvalidTransfer) {
+			t.Errorf("transfer to %s: err = %v, want ErrInvalidTransfer", name, err)
+		}
+	}
+
+	transfer, err := f.svc.RequestTransfer(ctx, form.ID, alice, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transfer.Status != models.TransferStatusPending || !transfer.ExpiresAt.Equal(f.clock.now().Add(models.TransferTTL)) {
+		t.Errorf("transfer = %+v, want pending for 7 days", transfer)
+	}
+	if _, err := f.svc.RequestTransfer(ctx, form.ID, alice, to); !errors.Is(err, ErrTransferPending) {
+		t.Errorf("second request: err = %v, want ErrTransferPending", err)
+	}
+
+	// Both sides list it; members who can't accept it don't see it
+	for user, want := range map[uuid.UUID]int{alice: 1, bob: 1, carol: 0, stranger: 0} {
+		transfers, err := f.svc.ListTransfers(ctx, user, models.TransferStatusPending)
+		if err != nil || len(transfers) != want {
+			t.Errorf("ListTransfers = %d transfers, %v; want %d", len(transfers), err, want)
+		}
+	}
+	for _, user := range []uuid.UUID{carol, stranger} {
+		if _, err := f.svc.AcceptTransfer(ctx, transfer.ID, user); !errors.Is(err, ErrTransferNotFound) {
+			t.Errorf("non-party accepts: err = %v, want ErrTransferNotFound", err)
+		}
+	}
+	if _, err := f.svc.AcceptTransfer(ctx, transfer.ID, alice); !errors.Is(err, ErrInsufficientOrganizationRole) {
+		t.Errorf("source accepts: err = %v, want ErrInsufficientOrganizationRole", err)
+	}
+
+	accepted, err := f.svc.AcceptTransfer(ctx, transfer.ID, bob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if accepted.Status != models.TransferStatusAccepted || accepted.ResolvedBy == nil || *accepted.ResolvedBy != bob {
+		t.Errorf("accepted transfer = %+v", accepted)
+	}
+	moved, _ := f.forms.GetByID(ctx, form.ID)
+	if moved.UserID != bob || moved.OrganizationID != acme.ID {
+		t.Errorf("form owned by %s in %s, want bob in Acme", moved.UserID, moved.OrganizationID)
+	}
+	if len(f.publisher.events) != 1 || f.publisher.events[0] != events.FormTransferred {
+		t.Fatalf("published %v, want form.transferred", f.publisher.events)
+	}
+	data := f.publisher.data[0]
+	if data["organization_id"] != acme.ID.String() || data["previous_organization_id"] != form.OrganizationID.String() || data["previous_owner_id"] != alice.String() {
+		t.Errorf("event data = %v", data)
+	}
+	actions := map[string]bool{}
+	for _, event := range f.auditor.events {
+		actions[event.Action] = true
+	}
+	if !actions[events.AuditTransferRequested] || !actions[events.AuditFormTransferred] {
+		t.Errorf("audited %v, want the request and the transfer", actions)
+	}
+
+	if _, err := f.svc.AcceptTransfer(ctx, transfer.ID, bob); !errors.Is(err, ErrTransferNotPending) {
+		t.Errorf("accepting twice: err = %v, want ErrTransferNotPending", err)
+	}
+	if _, err := f.svc.CancelTransfer(ctx, transfer.ID, alice); !errors.Is(err, ErrTransferNotPending) {
+		t.Errorf("cancelling once accepted: err = %v, want ErrTransferNotPending", err)
+	}
+}
+
+func TestTransferToUser(t *testing.T) {
+	ctx := context.Background()
+	f := newTransferFixture(t)
+	alice, bob, dave := uuid.New(), uuid.New(), uuid.New()
+
+	// Forms of personal organizations move to the personal organization of
+	// the user
+	personalForm := f.form(t, alice, nil)
+	transfer, err := f.svc.RequestTransfer(ctx, personalForm.ID, alice, TransferFormRequest{ToUserID: &dave})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.svc.AcceptTransfer(ctx, transfer.ID, bob); !errors.Is(err, ErrTransferNotFound) {
+		t.Errorf("another user accepts: err = %v, want ErrTransferNotFound", err)
+	}
+	if _, err := f.svc.AcceptTransfer(ctx, transfer.ID, dave); err != nil {
+		t.Fatal(err)
+	}
+	daves, _ := f.orgs.Personal(ctx, dave)
+	moved, _ := f.forms.GetByID(ctx, personalForm.ID)
+	if moved.UserID != dave || moved.OrganizationID != daves.ID {
+		t.Errorf("form owned by %s in %s, want dave in the personal organization of dave", moved.UserID, moved.OrganizationID)
+	}
+
+	// Forms of team organizations stay in them, and only go to members
+	acme := f.createOrganization(t, bob, map[uuid.UUID]models.OrganizationRole{alice: models.OrganizationRoleAdmin})
+	teamForm := f.form(t, alice, acme)
+	if _, err := f.svc.RequestTransfer(ctx, teamForm.ID, alice, TransferFormRequest{ToUserID: &dave}); !errors.Is(err, ErrInvalidTransfer) {
+		t.Errorf("transfer to a non-member: err = %v, want ErrInvalidTransfer", err)
+	}
+	// An owner of the organization transfers the forms of its members
+	transfer, err = f.svc.RequestTransfer(ctx, teamForm.ID, bob, TransferFormRequest{ToUserID: &bob})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.svc.AcceptTransfer(ctx, transfer.ID, bob); err != nil {
+		t.Fatal(err)
+	}
+	moved, _ = f.forms.GetByID(ctx, teamForm.ID)
+	if moved.UserID != bob || moved.OrganizationID != acme.ID {
+		t.Errorf("form owned by %s in %s, want bob in Acme", moved.UserID, moved.OrganizationID)
+	}
+}
+
+func TestTransferExpiry(t *testing.T) {
+	ctx := context.Background()
+	f := newTransferFixture(t)
+	alice, dave := uuid.New(), uuid.New()
+	form := f.form(t, alice, nil)
+
+	transfer, err := f.svc.RequestTransfer(ctx, form.ID, alice, TransferFormRequest{ToUserID: &dave})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.clock.advance(models.TransferTTL)
+	if _, err := f.svc.AcceptTransfer(ctx, transfer.ID, dave); !errors.Is(err, ErrTransferExpired) {
+		t.Fatalf("accept after 7 days: err = %v, want ErrTransferExpired", err)
+	}
+	if _, err := f.svc.CancelTransfer(ctx, transfer.ID, alice); !errors.Is(err, ErrTransferExpired) {
+		t.Errorf("cancel after 7 days: err = %v, want ErrTransferExpired", err)
+	}
+	expired, err := f.svc.ListTransfers(ctx, dave, models.TransferStatusExpired)
+	if err != nil || len(expired) != 1 || expired[0].ID != transfer.ID {
+		t.Errorf("expired transfers = %v, %v; want the transfer", expired, err)
+	}
+	if moved, _ := f.forms.GetByID(ctx, form.ID); moved.UserID != alice {
+		t.Errorf("expired transfer moved the form to %s", moved.UserID)
+	}
+
+	// The form can be transferred again
+	if _, err := f.svc.RequestTransfer(ctx, form.ID, alice, TransferFormRequest{ToUserID: &dave}); err != nil {
+		t.Errorf("request after the expiry: %v", err)
+	}
+	if _, err := f.svc.ListTransfers(ctx, dave, "open"); !errors.Is(err, ErrInvalidTransfer) {
+		t.Errorf("list with an unknown status: err = %v, want ErrInvalidTransfer", err)
+	}
+}
+
+func TestTransferQuota(t *testing.T) {
+	ctx := context.Background()
+	f := newTransferFixture(t)
+	alice, bob := uuid.New(), uuid.New()
+	acme := f.createOrganization(t, bob, nil)
+	f.form(t, bob, acme)
+	form := f.form(t, alice, nil)
+
+	// Acme takes a second active form at the request, not a third at the
+	// accept
+	transfer, err := f.svc.RequestTransfer(ctx, form.ID, alice, TransferFormRequest{ToOrganizationID: &acme.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.form(t, bob, acme)
+	if _, err := f.svc.AcceptTransfer(ctx, transfer.ID, bob); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("accept over the quota: err = %v, want ErrQuotaExceeded", err)
+	}
+	if pending, _ := f.transfers.GetByID(ctx, transfer.ID); pending.Status != models.TransferStatusPending {
+		t.Errorf("transfer %s after the quota refused it, want pending", pending.Status)
+	}
+	if _, err := f.svc.CancelTransfer(ctx, transfer.ID, bob); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.svc.RequestTransfer(ctx, form.ID, alice, TransferFormRequest{ToOrganizationID: &acme.ID}); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("request over the quota: err = %v, want ErrQuotaExceeded", err)
+	}
+
+	// Closed forms don't count
+	form.Status = models.FormStatusClosed
+	if err := f.forms.Update(ctx, form); err != nil {
+		t.Fatal(err)
+	}
+	transfer, err = f.svc.RequestTransfer(ctx, form.ID, alice, TransferFormRequest{ToOrganizationID: &acme.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.svc.AcceptTransfer(ctx, transfer.ID, bob); err != nil {
+		t.Errorf("accept of a closed form: %v", err)
+	}
+}
+
+func TestTransferAcceptCancelRace(t *testing.T) {
+	ctx := context.Background()
+	f := newTransferFixture(t)
+	f.svc.usage = nil
+	alice, dave := uuid.New(), uuid.New()
+
+	for i := 0; i < 20; i++ {
+		form := f.form(t, alice, nil)
+		transfer, err := f.svc.RequestTransfer(ctx, form.ID, alice, TransferFormRequest{ToUserID: &dave})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var wg sync.WaitGroup
+		var acceptErr, cancelErr error
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, acceptErr = f.svc.AcceptTransfer(ctx, transfer.ID, dave)
+		}()
+		go func() {
+			defer wg.Done()
+			_, cancelErr = f.svc.CancelTransfer(ctx, transfer.ID, alice)
+		}()
+		wg.Wait()
+
+		if (acceptErr == nil) == (cancelErr == nil) {
+			t.Fatalf("accept: %v, cancel: %v; want exactly one to win", acceptErr, cancelErr)
+		}
+		loser := acceptErr
+		if loser == nil {
+			loser = cancelErr
+		}
+		if !errors.Is(loser, ErrTransferNotPending) {
+			t.Errorf("losing request: err = %v, want ErrTransferNotPending", loser)
+		}
+		resolved, _ := f.transfers.GetByID(ctx, transfer.ID)
+		moved, _ := f.forms.GetByID(ctx, form.ID)
+		if (resolved.Status == models.TransferStatusAccepted) != (moved.UserID == dave) {
+			t.Errorf("transfer %s but the form is owned by %s", resolved.Status, moved.UserID)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+	"github.com/Mir00r/X-Form-Backend/shared/storage"
+)
+
+const (
+	// uploadURLTTL is how long a pre-signed upload URL stays valid
+	uploadURLTTL = 15 * time.Minute
+	// OrphanedUploadMaxAge is how long an upload may stay unattached before cleanup
+	OrphanedUploadMaxAge = 24 * time.Hour
+	// orphanCleanupBatchSize bounds the work done per cleanup run
+	orphanCleanupBatchSize = 500
+)
+
+// ErrUploadRejected is returned when an upload or file token violates the question constraints
+var ErrUploadRejected = errors.New("upload rejected")
+
+var unsafeFileNameChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// UploadService defines the interface for file question uploads
+type UploadService interface {
+	CreateUploadURL(ctx context.Context, formID, questionID uuid.UUID, req CreateUploadURLRequest) (*UploadURLResponse, error)
+	VerifyFileTokens(ctx context.Context, formID, questionID uuid.UUID, req VerifyFileTokensRequest) ([]*models.FileUpload, error)
+	CleanupOrphanedUploads(ctx context.Context, maxAge time.Duration) (int, error)
+	RunOrphanCleanup(ctx context.Context, interval time.Duration)
+}
+
+// CreateUploadURLRequest represents a request for a pre-signed upload URL
+type CreateUploadURLRequest struct {
+	FileName    string `json:"file_name" binding:"required,max=255"`
+	ContentType string `json:"content_type" binding:"required,max=255"`
+	SizeBytes   int64  `json:"size_bytes" binding:"required,min=1"`
+}
+
+// UploadURLResponse carries everything a client needs to upload a file
+type UploadURLResponse struct {
+	UploadURL string            `json:"upload_url"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers"`
+	FileToken string            `json:"file_token"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// VerifyFileTokensRequest represents the file tokens submitted as an answer
+type VerifyFileTokensRequest struct {
+	Tokens     []string `json:"tokens" binding:"required"`
+	ResponseID string   `json:"response_id" binding:"required,max=100"`
+}
+
+// uploadService implements UploadService interface
+type uploadService struct {
+	uploadRepo   repository.FileUploadRepository
+	questionRepo repository.QuestionRepository
+	storage      storage.Backend
+	publisher    events.Publisher
+	files        FileService
+	usage        *UsageMeter
+	now          func() time.Time
+}
+
+// NewUploadService creates a new upload service instance. Completed uploads
+// are announced on the event bus and handed to files for scanning. The bytes
+// of the files attached are metered by usage, which may be nil.
+func NewUploadService(uploadRepo repository.FileUploadRepository, questionRepo repository.QuestionRepository, store storage.Backend, publisher events.Publisher, files FileService, usage *UsageMeter) UploadService {
+	return &uploadService{
+		uploadRepo:   uploadRepo,
+		questionRepo: questionRepo,
+		storage:      store,
+		publisher:    publisher,
+		files:        files,
+		usage:        usage,
+		now:          time.Now,
+	}
+}
+
+// CreateUploadURL checks the declared file against the question constraints and
+// returns a pre-signed PUT URL together with the token the respondent submits
+func (s *uploadService) CreateUploadURL(ctx context.Context, formID, questionID uuid.UUID, req CreateUploadURLRequest) (*UploadURLResponse, error) {
+	question, constraints, err := s.fileQuestion(ctx, formID, questionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if question.Form != nil && question.Form.Status != models.FormStatusPublished {
+		return nil, fmt.Errorf("%w: form is not accepting responses", ErrUploadRejected)
+	}
+	if !constraints.AllowsMimeType(req.ContentType) {
+		return nil, fmt.Errorf("%w: content type %s is not allowed", ErrUploadRejected, req.ContentType)
+	}
+	if req.SizeBytes > constraints.MaxSizeBytes {
+		return nil, fmt.Errorf("%w: file exceeds the maximum size of %d bytes", ErrUploadRejected, constraints.MaxSizeBytes)
+	}
+
+	token, err := newFileToken()
+	if err != nil {
+		return nil, err
+	}
+
+	uploadID := uuid.New()
+	upload := &models.FileUpload{
+		ID:          uploadID,
+		Token:       token,
+		FormID:      formID,
+		QuestionID:  questionID,
+		ObjectKey:   path.Join("uploads", formID.String(), questionID.String(), uploadID.String(), sanitizeFileName(req.FileName)),
+		FileName:    req.FileName,
+		ContentType: req.ContentType,
+		SizeBytes:   req.SizeBytes,
+	}
+
+	uploadURL, err := s.storage.PresignPut(ctx, upload.ObjectKey, upload.ContentType, uploadURLTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign upload: %w", err)
+	}
+
+	if err := s.uploadRepo.Create(ctx, upload); err != nil {
+		return nil, fmt.Errorf("failed to create upload: %w", err)
+	}
+
+	return &UploadURLResponse{
+		UploadURL: uploadURL,
+		Method:    "PUT",
+		Headers:   map[string]string{"Content-Type": upload.ContentType},
+		FileToken: token,
+		ExpiresAt: s.now().Add(uploadURLTTL).UTC(),
+	}, nil
+}
+
+// VerifyFileTokens validates the tokens of a submitted answer and attaches the
+// uploads to the response. Every token must belong to the question and point to
+// an uploaded object that satisfies the question constraints. Tokens of uploads
+// left unattached for OrphanedUploadMaxAge have expired, as their files are
+// about to be cleaned up.
+func (s *uploadService) VerifyFileTokens(ctx context.Context, formID, questionID uuid.UUID, req VerifyFileTokensRequest) ([]*models.FileUpload, error) {
+	_, constraints, err := s.fileQuestion(ctx, formID, questionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.Tokens) > constraints.MaxCount {
+		return nil, fmt.Errorf("%w: at most %d files are allowed", ErrUploadRejected, constraints.MaxCount)
+	}
+
+	now := s.now()
+	uploads := make([]*models.FileUpload, 0, len(req.Tokens))
+	for _, token := range req.Tokens {
+		upload, err := s.uploadRepo.GetByToken(ctx, token)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unknown file token", ErrUploadRejected)
+		}
+		if upload.FormID != formID || upload.QuestionID != questionID {
+			return nil, fmt.Errorf("%w: file token does not belong to this question", ErrUploadRejected)
+		}
+		if upload.Status == models.FileUploadStatusAttached && upload.ResponseID != req.ResponseID {
+			return nil, fmt.Errorf("%w: file token has already been used", ErrUploadRejected)
+		}
+		if upload.Status == models.FileUploadStatusPending && !upload.CreatedAt.After(now.Add(-OrphanedUploadMaxAge)) {
+			return nil, fmt.Errorf("%w: file token has expired", ErrUploadRejected)
+		}
+
+		object, err := s.storage.Stat(ctx, upload.ObjectKey)
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return nil, fmt.Errorf("%w: file %s has not been uploaded", ErrUploadRejected, upload.FileName)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to check uploaded file: %w", err)
+		}
+		if object.Size > constraints.MaxSizeBytes {
+			return nil, fmt.Errorf("%w: file %s exceeds the maximum size of %d bytes", ErrUploadRejected, upload.FileName, constraints.MaxSizeBytes)
+		}
+		if !constraints.AllowsMimeType(object.ContentType) {
+			return nil, fmt.Errorf("%w: content type %s is not allowed", ErrUploadRejected, object.ContentType)
+		}
+
+		upload.SizeBytes = object.Size
+		uploads = append(uploads, upload)
+	}
+
+	for _, upload := range uploads {
+		if upload.Status == models.FileUploadStatusAttached {
+			continue
+		}
+
+		upload.Status = models.FileUploadStatusAttached
+		upload.ResponseID = req.ResponseID
+		upload.AttachedAt = &now
+		if err := s.uploadRepo.Update(ctx, upload); err != nil {
+			return nil, fmt.Errorf("failed to attach upload: %w", err)
+		}
+
+		s.announceUpload(ctx, upload)
+		s.usage.recordForForm(ctx, formID, models.UsageStorageBytes, upload.ID.String(), upload.SizeBytes)
+	}
+
+	return uploads, nil
+}
+
+// CleanupOrphanedUploads deletes uploads that were never attached to a response
+// within maxAge, returning how many were removed
+func (s *uploadService) CleanupOrphanedUploads(ctx context.Context, maxAge time.Duration) (int, error) {
+	uploads, err := s.uploadRepo.ListPendingBefore(ctx, s.now().Add(-maxAge), orphanCleanupBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list orphaned uploads: %w", err)
+	}
+
+	removed := 0
+	for _, upload := range uploads {
+		if err := s.storage.Delete(ctx, upload.ObjectKey); err != nil {
+			return removed, fmt.Errorf("failed to delete object %s: %w", upload.ObjectKey, err)
+		}
+		if err := s.uploadRepo.Delete(ctx, upload.ID); err != nil {
+			return removed, fmt.Errorf("failed to delete upload %s: %w", upload.ID, err)
+		}
+		removed++
+	}
+
+	return removed, nil
+}
+
+// RunOrphanCleanup runs CleanupOrphanedUploads every interval until ctx is cancelled
+func (s *uploadService) RunOrphanCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			removed, err := s.CleanupOrphanedUploads(ctx, OrphanedUploadMaxAge)
+			if err != nil {
+				log.Printf("Orphaned upload cleanup failed: %v", err)
+				continue
+			}
+			if removed > 0 {
+				log.Printf("Removed %d orphaned uploads", removed)
+			}
+		}
+	}
+}
+
+// announceUpload publishes file.uploaded and schedules the antivirus scan
+func (s *uploadService) announceUpload(ctx context.Context, upload *models.FileUpload) {
+	err := s.publisher.Publish(ctx, events.FileUploaded, upload.FormID.String(), map[string]interface{}{
+		"file_id":      upload.ID.String(),
+		"form_id":      upload.FormID.String(),
+		"question_id":  upload.QuestionID.String(),
+		"response_id":  upload.ResponseID,
+		"object_key":   upload.ObjectKey,
+		"content_type": upload.ContentType,
+		"size_bytes":   upload.SizeBytes,
+	})
+	if err != nil {
+		log.Printf("Failed to publish %s event for file %s: %v", events.FileUploaded, upload.ID, err)
+	}
+
+	s.files.Enqueue(upload)
+}
+
+// fileQuestion loads a question and ensures it is a file question of the form
+func (s *uploadService) fileQuestion(ctx context.Context, formID, questionID uuid.UUID) (*models.Question, models.FileConstraints, error) {
+	question, err := s.questionRepo.GetByID(ctx, questionID)
+	if err != nil {
+		return nil, models.FileConstraints{}, fmt.Errorf("failed to get question: %w", err)
+	}
+	if question.FormID != formID {
+		return nil, models.FileConstraints{}, fmt.Errorf("question %s does not belong to form %s", questionID, formID)
+	}
+	if question.Type != models.QuestionTypeFile {
+		return nil, models.FileConstraints{}, fmt.Errorf("%w: question %s is not a file question", ErrUploadRejected, questionID)
+	}
+
+	constraints, err := question.GetFileConstraints()
+	if err != nil {
+		return nil, models.FileConstraints{}, err
+	}
+
+	return question, constraints, nil
+}
+
+// newFileToken generates an unguessable token for a file upload
+func newFileToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate file token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// sanitizeFileName keeps object keys free of path separators and odd characters
+func sanitizeFileName(name string) string {
+	name = unsafeFileNameChars.ReplaceAllString(path.Base(name), "_")
+	if name == "" || name == "." || name == ".." {
+		return "file"
+	}
+	return name
+}
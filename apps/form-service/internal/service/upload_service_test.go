@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/shared/storage"
+)
+
+// recordingFiles records the uploads handed over for scanning
+type recordingFiles struct {
+	FileService
+	enqueued []uuid.UUID
+}
+
+func (f *recordingFiles) Enqueue(upload *models.FileUpload) {
+	f.enqueued = append(f.enqueued, upload.ID)
+}
+
+type uploadFixture struct {
+	*memoryStore
+	svc     *uploadService
+	uploads *memoryFileUploads
+	store   storage.Backend
+	files   *recordingFiles
+	form    *models.Form
+	cv      *models.Question
+	photo   *models.Question
+}
+
+// newUploadFixture creates a published form asking for a PDF CV and a photo
+func newUploadFixture(t *testing.T) *uploadFixture {
+	t.Helper()
+	store, err := storage.NewLocalStorage(t.TempDir(), "http://localhost:8001", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &uploadFixture{memoryStore: newMemoryStore(time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)), store: store, files: &recordingFiles{}}
+	f.uploads = &memoryFileUploads{now: f.clock.now}
+	f.form = f.createForm(t, &models.Form{UserID: uuid.New(), Title: "Applications", Status: models.FormStatusPublished})
+	pdfOnly, _ := json.Marshal(models.FileConstraints{AllowedMimeTypes: []string{"application/pdf"}, MaxSizeBytes: 1024})
+	f.cv = &models.Question{FormID: f.form.ID, Type: models.QuestionTypeFile, Title: "CV", Validation: pdfOnly}
+	f.photo = &models.Question{FormID: f.form.ID, Type: models.QuestionTypeFile, Title: "Photo"}
+	f.createQuestions(t, f.cv, f.photo)
+
+	f.svc = NewUploadService(f.uploads, f.questions, store, &recordingPublisher{}, f.files, nil).(*uploadService)
+	f.svc.now = f.clock.now
+	return f
+}
+
+// upload requests an upload URL for the question and stores body at its key,
+// returning the file token
+func (f *uploadFixture) upload(t *testing.T, question *models.Question, body []byte) string {
+	t.Helper()
+	ctx := context.Background()
+	issued, err := f.svc.CreateUploadURL(ctx, f.form.ID, question.ID, CreateUploadURLRequest{
+		FileName: "cv.pdf", ContentType: "application/pdf", SizeBytes: int64(len(body)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	upload, err := f.uploads.GetByToken(ctx, issued.FileToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.store.Put(ctx, upload.ObjectKey, "application/pdf", body); err != nil {
+		t.Fatal(err)
+	}
+	return issued.FileToken
+}
+
+func (f *uploadFixture) verify(question *models.Question, responseID string, tokens ...string) ([]*models.FileUpload, error) {
+	return f.svc.VerifyFileTokens(context.Background(), f.form.ID, question.ID, VerifyFileTokensRequest{Tokens: tokens, ResponseID: responseID})
+}
+
+func TestVerifyFileTokens(t *testing.T) {
+	f := newUploadFixture(t)
+	token := f.upload(t, f.cv, []byte("%PDF-1.7"))
+
+	attached, err := f.verify(f.cv, "resp-1", token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attached) != 1 || attached[0].Status != models.FileUploadStatusAttached || attached[0].ResponseID != "resp-1" {
+		t.Fatalf("attached = %+v, want the upload attached to resp-1", attached)
+	}
+	if len(f.files.enqueued) != 1 || f.files.enqueued[0] != attached[0].ID {
+		t.Errorf("enqueued for scanning = %v, want the attached upload", f.files.enqueued)
+	}
+
+	// Submitting the same answer again is idempotent, reusing the token for
+	// another response is not
+	if _, err := f.verify(f.cv, "resp-1", token); err != nil {
+		t.Errorf("resubmission rejected: %v", err)
+	}
+	if _, err := f.verify(f.cv, "resp-2", token); !errors.Is(err, ErrUploadRejected) {
+		t.Errorf("token reused by another response: err = %v, want ErrUploadRejected", err)
+	}
+}
+
+func TestVerifyFileTokensRejectsForeignTokens(t *testing.T) {
+	f := newUploadFixture(t)
+	token := f.upload(t, f.cv, []byte("%PDF-1.7"))
+
+	if _, err := f.verify(f.cv, "resp-1", strings.Repeat("0", len(token))); !errors.Is(err, ErrUploadRejected) {
+		t.Errorf("forged token: err = %v, want ErrUploadRejected", err)
+	}
+	if _, err := f.verify(f.photo, "resp-1", token); !errors.Is(err, ErrUploadRejected) {
+		t.Errorf("token of another question: err = %v, want ErrUploadRejected", err)
+	}
+
+	// A token of the same question ID submitted to another form
+	other := f.createForm(t, &models.Form{UserID: f.form.UserID, Title: "Other", Status: models.FormStatusPublished})
+	if _, err := f.svc.VerifyFileTokens(context.Background(), other.ID, f.cv.ID, VerifyFileTokensRequest{Tokens: []string{token}, ResponseID: "resp-1"}); err == nil {
+		t.Error("token accepted for another form")
+	}
+	otherCV := &models.Question{FormID: other.ID, Type: models.QuestionTypeFile, Title: "CV"}
+	f.createQuestions(t, otherCV)
+	if _, err := f.svc.VerifyFileTokens(context.Background(), other.ID, otherCV.ID, VerifyFileTokensRequest{Tokens: []string{token}, ResponseID: "resp-1"}); !errors.Is(err, ErrUploadRejected) {
+		t.Errorf("token of another form: err = %v, want ErrUploadRejected", err)
+	}
+
+	if upload, _ := f.uploads.GetByToken(context.Background(), token); upload.Status != models.FileUploadStatusPending {
+		t.Errorf("rejected token attached the upload: %+v", upload)
+	}
+	if len(f.files.enqueued) != 0 {
+		t.Errorf("rejected uploads enqueued for scanning: %v", f.files.enqueued)
+	}
+}
+
+func TestVerifyFileTokensRejectsExpiredTokens(t *testing.T) {
+	f := newUploadFixture(t)
+	token := f.upload(t, f.cv, []byte("%PDF-1.7"))
+
+	f.clock.advance(OrphanedUploadMaxAge - time.Minute)
+	fresh := f.upload(t, f.cv, []byte("%PDF-1.7"))
+	f.clock.advance(time.Minute)
+	if _, err := f.verify(f.cv, "resp-1", token); !errors.Is(err, ErrUploadRejected) {
+		t.Errorf("token of an upload left unattached for a day: err = %v, want ErrUploadRejected", err)
+	}
+	if _, err := f.verify(f.cv, "resp-1", fresh); err != nil {
+		t.Errorf("token of a recent upload rejected: %v", err)
+	}
+}
+
+func TestVerifyFileTokensChecksTheUploadedObject(t *testing.T) {
+	f := newUploadFixture(t)
+	ctx := context.Background()
+
+	issued, err := f.svc.CreateUploadURL(ctx, f.form.ID, f.cv.ID, CreateUploadURLRequest{FileName: "cv.pdf", ContentType: "application/pdf", SizeBytes: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.verify(f.cv, "resp-1", issued.FileToken); !errors.Is(err, ErrUploadRejected) {
+		t.Errorf("token of a file never uploaded: err = %v, want ErrUploadRejected", err)
+	}
+
+	// The respondent uploads more than declared
+	upload, err := f.uploads.GetByToken(ctx, issued.FileToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.store.Put(ctx, upload.ObjectKey, "application/pdf", make([]byte, 1025)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.verify(f.cv, "resp-1", issued.FileToken); !errors.Is(err, ErrUploadRejected) {
+		t.Errorf("file larger than allowed: err = %v, want ErrUploadRejected", err)
+	}
+	if err := f.store.Put(ctx, upload.ObjectKey, "text/html", []byte("<html>")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.verify(f.cv, "resp-1", issued.FileToken); !errors.Is(err, ErrUploadRejected) {
+		t.Errorf("file of a type not allowed: err = %v, want ErrUploadRejected", err)
+	}
+}
+
+func TestCleanupOrphanedUploads(t *testing.T) {
+	f := newUploadFixture(t)
+	ctx := context.Background()
+
+	orphan := f.upload(t, f.cv, []byte("%PDF-1.7"))
+	attached := f.upload(t, f.cv, []byte("%PDF-1.7"))
+	if _, err := f.verify(f.cv, "resp-1", attached); err != nil {
+		t.Fatal(err)
+	}
+	f.clock.advance(OrphanedUploadMaxAge + time.Minute)
+	recent := f.upload(t, f.cv, []byte("%PDF-1.7"))
+
+	removed, err := f.svc.CleanupOrphanedUploads(ctx, OrphanedUploadMaxAge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 1 {
+		t.Fatalf("removed %d uploads, want only the orphan", removed)
+	}
+
+	for token, kept := range map[string]bool{orphan: false, attached: true, recent: true} {
+		upload, err := f.uploads.GetByToken(ctx, token)
+		if !kept {
+			if err == nil {
+				t.Errorf("orphaned upload %s kept", upload.ID)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("upload deleted: %v", err)
+		}
+		if _, err := f.store.Stat(ctx, upload.ObjectKey); err != nil {
+			t.Errorf("object %s deleted: %v", upload.ObjectKey, err)
+		}
+	}
+	if keys := listObjects(t, f.store, "uploads/"); len(keys) != 2 {
+		t.Errorf("objects left = %v, want the attached and recent uploads", keys)
+	}
+}
+
+// listObjects lists the keys stored under prefix
+func listObjects(t *testing.T, store storage.Backend, prefix string) []string {
+	t.Helper()
+	var keys []string
+	err := store.List(context.Background(), prefix, func(object storage.ObjectInfo) error {
+		keys = append(keys, object.Key)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
@@ -0,0 +1,548 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/analytics"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+)
+
+var (
+	// ErrQuotaExceeded is returned for operations beyond a quota of the
+	// plan of the organization; reads keep working
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	// ErrInvalidUsageMonth is returned for months not given as YYYY-MM
+	ErrInvalidUsageMonth = errors.New("invalid usage month")
+)
+
+// usageFlushBatch bounds the organization months flushed at once
+const usageFlushBatch = 100
+
+// UsageMeter counts the usage of organizations for billing, and enforces the
+// quotas of their plans. Usage is counted in Redis as it happens and flushed
+// to the usage table periodically. Metering never fails the operation
+// metered: a count lost to an outage is restored by reconciliation.
+type UsageMeter struct {
+	counter repository.UsageCounter
+	usage   repository.UsageRepository
+	forms   repository.FormRepository
+	orgs    repository.OrganizationRepository
+	plans   map[string]models.Plan
+	// enforce is false to meter without enforcing quotas
+	enforce bool
+	now     func() time.Time
+}
+
+// NewUsageMeter creates a meter enforcing the quotas of plans, by name, when
+// enforce is set. Organizations on a plan missing from plans have no quotas.
+func NewUsageMeter(counter repository.UsageCounter, usage repository.UsageRepository, forms repository.FormRepository, orgs repository.OrganizationRepository, plans map[string]models.Plan, enforce bool) *UsageMeter {
+	return &UsageMeter{
+		counter: counter,
+		usage:   usage,
+		forms:   forms,
+		orgs:    orgs,
+		plans:   plans,
+		enforce: enforce,
+		now:     time.Now,
+	}
+}
+
+// record counts quantity of metric for the organization, once for id. A nil
+// meter records nothing.
+func (m *UsageMeter) record(ctx context.Context, orgID uuid.UUID, metric models.UsageMetric, id string, quantity int64) {
+	if m == nil {
+		return
+	}
+	event := &models.UsageEvent{ID: id, OrganizationID: orgID, Metric: metric, Quantity: quantity, At: m.now()}
+	if _, err := m.counter.Add(ctx, event); err != nil {
+		log.Printf("Failed to meter %s %s of organization %s: %v", metric, id, orgID, err)
+	}
+}
+
+// recordForForm counts quantity of metric for the organization of the form
+func (m *UsageMeter) recordForForm(ctx context.Context, formID uuid.UUID, metric models.UsageMetric, id string, quantity int64) {
+	if m == nil {
+		return
+	}
+	form, err := m.forms.GetByID(ctx, formID)
+	if err != nil {
+		log.Printf("Failed to meter %s %s of form %s: %v", metric, id, formID, err)
+		return
+	}
+	m.record(ctx, form.OrganizationID, metric, id, quantity)
+}
+
+// checkActiveForms returns ErrQuotaExceeded when the organization has as many
+// active forms as its plan allows
+func (m *UsageMeter) checkActiveForms(ctx context.Context, orgID uuid.UUID) error {
+	if m == nil || !m.enforce {
+		return nil
+	}
+	plan, err := m.plan(ctx, orgID)
+	if err != nil || plan.MaxActiveForms == 0 {
+		return err
+	}
+	active, err := m.usage.CountActiveForms(ctx, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to count active forms: %w", err)
+	}
+	if active >= plan.MaxActiveForms {
+		return fmt.Errorf("%w: the %s plan allows %d active forms; close a form or upgrade", ErrQuotaExceeded, plan.Name, plan.MaxActiveForms)
+	}
+	return nil
+}
+
+// admitResponse counts the response of the form unless the organization
+// collected as many responses this month as its plan allows. A response
+// admitted again, such as a retried submission, is admitted without being
+// counted twice.
+func (m *UsageMeter) admitResponse(ctx context.Context, form *models.Form, responseID string) error {
+	if m == nil {
+		return nil
+	}
+	if m.enforce {
+		plan, err := m.plan(ctx, form.OrganizationID)
+		if err != nil {
+			return err
+		}
+		if plan.MaxResponsesPerMonth > 0 {
+			usage, err := m.current(ctx, UsageKeyOf(form.OrganizationID, m.now()))
+			if err != nil {
+				return err
+			}
+			if usage.Responses >= plan.MaxResponsesPerMonth {
+				return fmt.Errorf("%w: the %s plan allows %d responses a month", ErrQuotaExceeded, plan.Name, plan.MaxResponsesPerMonth)
+			}
+		}
+	}
+	m.record(ctx, form.OrganizationID, models.UsageResponses, responseID, 1)
+	return nil
+}
+
+// releaseResponses gives the responses deleted from the forms of the
+// organization back to its quota: each is uncounted from the month it was
+// submitted in, once however often it is released. Test submissions were
+// never counted.
+func (m *UsageMeter) releaseResponses(ctx context.Context, orgID uuid.UUID, responses []models.DeletedResponse) {
+	if m == nil {
+		return
+	}
+	released := make(map[repository.UsageKey]int64)
+	for _, response := range responses {
+		if response.Test {
+			continue
+		}
+		event := &models.UsageEvent{
+			ID:             "deleted:" + response.ID,
+			OrganizationID: orgID,
+			Metric:         models.UsageResponses,
+			Quantity:       -1,
+			At:             response.SubmittedAt,
+		}
+		added, err := m.counter.Add(ctx, event)
+		if err != nil {
+			log.Printf("Failed to release response %s of organization %s: %v", response.ID, orgID, err)
+			continue
+		}
+		if added {
+			released[UsageKeyOf(orgID, response.SubmittedAt)]++
+		}
+	}
+	// The counts flushed already are lowered too, as flushing never lowers
+	// them
+	for key, quantity := range released {
+		if err := m.usage.Subtract(ctx, key, models.UsageResponses, quantity); err != nil {
+			log.Printf("Failed to release %d responses of organization %s in %s: %v", quantity, orgID, key.Month, err)
+		}
+	}
+}
+
+// plan returns the plan of the organization
+func (m *UsageMeter) plan(ctx context.Context, orgID uuid.UUID) (models.Plan, error) {
+	org, err := m.orgs.GetByID(ctx, orgID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.Plan{}, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return models.Plan{}, fmt.Errorf("failed to get organization: %w", err)
+	}
+	name := org.Plan
+	if name == "" {
+		name = models.DefaultPlan
+	}
+	plan, ok := m.plans[name]
+	if !ok {
+		return models.Plan{Name: name}, nil
+	}
+	plan.Name = name
+	return plan, nil
+}
+
+// current returns the usage of the organization month: the greater of the
+// counts flushed and the counts not flushed yet. The counter failing, the
+// flushed counts are returned.
+func (m *UsageMeter) current(ctx context.Context, key repository.UsageKey) (*models.OrganizationUsage, error) {
+	stored, err := m.usage.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage: %w", err)
+	}
+	counted, err := m.counter.Get(ctx, key)
+	if err != nil {
+		log.Printf("Failed to read the usage counters of organization %s: %v", key.OrganizationID, err)
+		return stored, nil
+	}
+	for _, metric := range models.UsageMetrics {
+		if counted.Get(metric) > stored.Get(metric) {
+			stored.Set(metric, counted.Get(metric))
+		}
+	}
+	return stored, nil
+}
+
+// Flush saves the counts of the organization months counted since the last
+// flush to the usage table. Months failing to save are flushed again next
+// time.
+func (m *UsageMeter) Flush(ctx context.Context) (int, error) {
+	flushed := 0
+	for {
+		keys, err := m.counter.TakeDirty(ctx, usageFlushBatch)
+		if err != nil {
+			return flushed, fmt.Errorf("failed to take usage counters: %w", err)
+		}
+		for i, key := range keys {
+			if err := m.flush(ctx, key); err != nil {
+				if markErr := m.counter.MarkDirty(context.WithoutCancel(ctx), keys[i:]); markErr != nil {
+					log.Printf("Failed to requeue usage counters: %v", markErr)
+				}
+				return flushed, err
+			}
+			flushed++
+		}
+		if len(keys) < usageFlushBatch {
+			return flushed, nil
+		}
+	}
+}
+
+func (m *UsageMeter) flush(ctx context.Context, key repository.UsageKey) error {
+	usage, err := m.counter.Get(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to read usage counters: %w", err)
+	}
+	usage.UpdatedAt = m.now()
+	if err := m.usage.Merge(ctx, usage); err != nil {
+		return fmt.Errorf("failed to save usage: %w", err)
+	}
+	return nil
+}
+
+// RunFlusher flushes the usage counters every interval until ctx is done
+func (m *UsageMeter) RunFlusher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// Save what was counted since the last tick
+			if _, err := m.Flush(context.WithoutCancel(ctx)); err != nil {
+				log.Printf("Usage flush failed: %v", err)
+			}
+			return
+		case <-ticker.C:
+			if _, err := m.Flush(ctx); err != nil {
+				log.Printf("Usage flush failed: %v", err)
+			}
+		}
+	}
+}
+
+// UsageKeyOf returns the key of the usage of the organization in the month
+// of t
+func UsageKeyOf(orgID uuid.UUID, t time.Time) repository.UsageKey {
+	return repository.UsageKey{OrganizationID: orgID, Month: models.UsageMonth(t)}
+}
+
+// UsageService reports the usage of organizations against the quotas of
+// their plans, admits responses to forms, and reconciles metered usage with
+// the source of truth
+type UsageService interface {
+	// GetUsage serves admins, relayed by the gateway
+	GetUsage(ctx context.Context, orgID uuid.UUID, month string) (*UsageReport, error)
+	// GetOwnUsage serves the owners and admins of the organization the
+	// user acts in
+	GetOwnUsage(ctx context.Context, userID uuid.UUID, month string) (*UsageReport, error)
+	// GetLimits serves the gateway the quotas of the organization the user
+	// acts in, reported to any of its members
+	GetLimits(ctx context.Context, userID uuid.UUID) (*LimitsReport, error)
+	// AdmitResponse is called by the response service before storing a
+	// response
+	AdmitResponse(ctx context.Context, formID uuid.UUID, responseID string) error
+	// Reconcile compares the usage of every organization in the month with
+	// the source of truth and corrects it
+	Reconcile(ctx context.Context, month string) (*ReconcileResult, error)
+	// RunReconciler reconciles the current and previous months every
+	// interval until ctx is done
+	RunReconciler(ctx context.Context, interval time.Duration)
+}
+
+// UsageReport is the usage of an organization in a month with the quotas of
+// its plan
+type UsageReport struct {
+	models.OrganizationUsage
+	Plan models.Plan `json:"plan"`
+	// ActiveForms are the forms not closed, counted against
+	// plan.max_active_forms
+	ActiveForms int64 `json:"active_forms"`
+}
+
+// ExportLimits bound the response exports running at a time
+type ExportLimits struct {
+	MaxConcurrentJobs      int `json:"max_concurrent_jobs" example:"4"`
+	MaxJobsPerOrganization int `json:"max_jobs_per_organization" example:"2"`
+}
+
+// LimitsReport is the usage of an organization this month with the quotas
+// of its plan and the bounds on its exports
+type LimitsReport struct {
+	UsageReport
+	Exports ExportLimits `json:"exports"`
+}
+
+// ReconcileResult counts the organizations reconciled and those whose
+// metered usage differed from the source of truth
+type ReconcileResult struct {
+	Month         string `json:"month"`
+	Organizations int    `json:"organizations"`
+	Drifted       int    `json:"drifted"`
+}
+
+// usageService implements UsageService interface
+type usageService struct {
+	meter     *UsageMeter
+	forms     repository.FormRepository
+	orgs      organizationScope
+	responses analytics.ResponseCounter
+	lock      repository.Lock
+	exports   ExportLimits
+}
+
+// NewUsageService creates a new usage service instance. Without responses,
+// reconciliation keeps the metered response counts; lock elects the replica
+// reconciling. exports are the bounds the export service is configured with.
+func NewUsageService(meter *UsageMeter, formRepo repository.FormRepository, orgRepo repository.OrganizationRepository, responses analytics.ResponseCounter, lock repository.Lock, exports ExportLimits) UsageService {
+	return &usageService{
+		meter:     meter,
+		forms:     formRepo,
+		orgs:      organizationScope{orgs: orgRepo},
+		responses: responses,
+		lock:      lock,
+		exports:   exports,
+	}
+}
+
+// GetUsage returns the usage of the organization in the month, the current
+// one when month is empty
+func (s *usageService) GetUsage(ctx context.Context, orgID uuid.UUID, month string) (*UsageReport, error) {
+	key, err := s.usageKey(orgID, month)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := s.meter.plan(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := s.meter.current(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	active, err := s.meter.usage.CountActiveForms(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count active forms: %w", err)
+	}
+	return &UsageReport{OrganizationUsage: *usage, Plan: plan, ActiveForms: active}, nil
+}
+
+// GetOwnUsage returns the usage of the organization the user acts in, or of
+// their personal organization
+func (s *usageService) GetOwnUsage(ctx context.Context, userID uuid.UUID, month string) (*UsageReport, error) {
+	orgID, err := s.orgs.home(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	member, err := s.orgs.orgs.GetMember(ctx, orgID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get member: %w", err)
+	}
+	if !member.Role.CanViewUsage() {
+		return nil, ErrInsufficientOrganizationRole
+	}
+	return s.GetUsage(ctx, orgID, month)
+}
+
+// GetLimits returns the quotas and usage this month of the organization the
+// user acts in, or of their personal organization. Unlike the usage report,
+// any member sees them, as they bound what the member can do.
+func (s *usageService) GetLimits(ctx context.Context, userID uuid.UUID) (*LimitsReport, error) {
+	orgID, err := s.orgs.home(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	report, err := s.GetUsage(ctx, orgID, "")
+	if err != nil {
+		return nil, err
+	}
+	return &LimitsReport{UsageReport: *report, Exports: s.exports}, nil
+}
+
+// AdmitResponse admits a response to a published form against the response
+// quota of its organization
+func (s *usageService) AdmitResponse(ctx context.Context, formID uuid.UUID, responseID string) error {
+	form, err := s.forms.GetByID(ctx, formID)
+	if err != nil {
+		return ErrFormNotFound
+	}
+	return s.meter.admitResponse(ctx, form, responseID)
+}
+
+func (s *usageService) usageKey(orgID uuid.UUID, month string) (repository.UsageKey, error) {
+	if month == "" {
+		return UsageKeyOf(orgID, s.meter.now()), nil
+	}
+	if _, err := models.ParseUsageMonth(month); err != nil {
+		return repository.UsageKey{}, fmt.Errorf("%w: %v", ErrInvalidUsageMonth, err)
+	}
+	return repository.UsageKey{OrganizationID: orgID, Month: month}, nil
+}
+
+// Reconcile counts the usage of each organization with usage in the month
+// from the tables of record and the response projection, logs the counts the
+// meter got wrong, and replaces them in the usage table and the counters.
+// Events counted while an organization is reconciled may be lost until the
+// next reconciliation.
+func (s *usageService) Reconcile(ctx context.Context, month string) (*ReconcileResult, error) {
+	start, err := models.ParseUsageMonth(month)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidUsageMonth, err)
+	}
+	end := start.AddDate(0, 1, 0)
+
+	// Counts not flushed yet would be compared stale
+	if _, err := s.meter.Flush(ctx); err != nil {
+		return nil, err
+	}
+	orgIDs, err := s.meter.usage.Organizations(ctx, month, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+
+	result := &ReconcileResult{Month: month}
+	for _, orgID := range orgIDs {
+		drifted, err := s.reconcile(ctx, orgID, month, start, end)
+		if err != nil {
+			return result, err
+		}
+		result.Organizations++
+		if drifted {
+			result.Drifted++
+		}
+	}
+	return result, nil
+}
+
+func (s *usageService) reconcile(ctx context.Context, orgID uuid.UUID, month string, start, end time.Time) (bool, error) {
+	key := repository.UsageKey{OrganizationID: orgID, Month: month}
+	metered, err := s.meter.usage.Get(ctx, key)
+	if err != nil {
+		return false, fmt.Errorf("failed to get usage: %w", err)
+	}
+	actual, err := s.meter.usage.Actual(ctx, orgID, start, end)
+	if err != nil {
+		return false, fmt.Errorf("failed to count usage: %w", err)
+	}
+	actual.Responses = metered.Responses
+	if s.responses != nil {
+		formIDs, err := s.meter.usage.FormIDs(ctx, orgID)
+		if err != nil {
+			return false, fmt.Errorf("failed to list forms: %w", err)
+		}
+		responses, err := s.responses.CountResponses(ctx, formIDs, start, end)
+		if err != nil {
+			return false, err
+		}
+		actual.Responses = responses
+	}
+
+	drifted := false
+	for _, metric := range models.UsageMetrics {
+		if metered.Get(metric) != actual.Get(metric) {
+			drifted = true
+			log.Printf("Usage drift of organization %s in %s: %s metered %d, actual %d",
+				orgID, month, metric, metered.Get(metric), actual.Get(metric))
+		}
+	}
+
+	now := s.meter.now()
+	actual.UpdatedAt = now
+	actual.ReconciledAt = &now
+	if err := s.meter.usage.Reconcile(ctx, actual); err != nil {
+		return false, fmt.Errorf("failed to save usage: %w", err)
+	}
+	// The counters would raise the corrected counts back on the next flush
+	if err := s.meter.counter.Set(ctx, actual); err != nil {
+		return false, fmt.Errorf("failed to reset usage counters: %w", err)
+	}
+	return drifted, nil
+}
+
+// RunReconciler reconciles the current month and the previous one, whose
+// last events may have arrived after it ended, while this replica holds the
+// reconciler lock
+func (s *usageService) RunReconciler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	defer func() {
+		if err := s.lock.Release(context.Background()); err != nil {
+			log.Printf("Failed to release the usage reconciler lock: %v", err)
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			leader, err := s.lock.Acquire(ctx, 3*interval)
+			if err != nil {
+				log.Printf("Usage reconciler lock unavailable: %v", err)
+				continue
+			}
+			if !leader {
+				continue
+			}
+			now := s.meter.now().UTC()
+			previous := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+			for _, month := range []string{models.UsageMonth(previous), models.UsageMonth(now)} {
+				result, err := s.Reconcile(ctx, month)
+				if err != nil {
+					log.Printf("Usage reconciliation of %s failed: %v", month, err)
+					continue
+				}
+				if result.Drifted > 0 {
+					log.Printf("Usage reconciliation of %s corrected %d of %d organizations", month, result.Drifted, result.Organizations)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,406 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/models"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/tenant"
+)
+
+// memoryUsageCounter counts usage events in memory the way the Redis
+// counter does
+type memoryUsageCounter struct {
+	mu     sync.Mutex
+	counts map[repository.UsageKey]models.OrganizationUsage
+	seen   map[string]bool
+	dirty  map[repository.UsageKey]bool
+}
+
+func newMemoryUsageCounter() *memoryUsageCounter {
+	return &memoryUsageCounter{
+		counts: make(map[repository.UsageKey]models.OrganizationUsage),
+		seen:   make(map[string]bool),
+		dirty:  make(map[repository.UsageKey]bool),
+	}
+}
+
+func (c *memoryUsageCounter) Add(_ context.Context, event *models.UsageEvent) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	id := string(event.Metric) + ":" + event.ID
+	if c.seen[id] {
+		return false, nil
+	}
+	c.seen[id] = true
+	key := UsageKeyOf(event.OrganizationID, event.At)
+	usage := c.counts[key]
+	usage.OrganizationID, usage.Month = key.OrganizationID, key.Month
+	usage.Set(event.Metric, usage.Get(event.Metric)+event.Quantity)
+	c.counts[key] = usage
+	c.dirty[key] = true
+	return true, nil
+}
+
+func (c *memoryUsageCounter) Get(_ context.Context, key repository.UsageKey) (*models.OrganizationUsage, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	usage := c.counts[key]
+	usage.OrganizationID, usage.Month = key.OrganizationID, key.Month
+	return &usage, nil
+}
+
+func (c *memoryUsageCounter) Set(_ context.Context, usage *models.OrganizationUsage) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[repository.UsageKey{OrganizationID: usage.OrganizationID, Month: usage.Month}] = *usage
+	return nil
+}
+
+func (c *memoryUsageCounter) TakeDirty(_ context.Context, n int) ([]repository.UsageKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var keys []repository.UsageKey
+	for key := range c.dirty {
+		if len(keys) == n {
+			break
+		}
+		keys = append(keys, key)
+		delete(c.dirty, key)
+	}
+	return keys, nil
+}
+
+func (c *memoryUsageCounter) MarkDirty(_ context.Context, keys []repository.UsageKey) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		c.dirty[key] = true
+	}
+	return nil
+}
+
+// memoryUsageRepository keeps usage rows in memory and counts the actual
+// usage from the forms of a memory form repository
+type memoryUsageRepository struct {
+	mu    sync.Mutex
+	rows  map[repository.UsageKey]models.OrganizationUsage
+	forms *memoryFormRepository
+}
+
+func newMemoryUsageRepository(forms *memoryFormRepository) *memoryUsageRepository {
+	return &memoryUsageRepository{rows: make(map[repository.UsageKey]models.OrganizationUsage), forms: forms}
+}
+
+func (r *memoryUsageRepository) Get(_ context.Context, key repository.UsageKey) (*models.OrganizationUsage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	usage := r.rows[key]
+	usage.OrganizationID, usage.Month = key.OrganizationID, key.Month
+	return &usage, nil
+}
+
+func (r *memoryUsageRepository) Merge(_ context.Context, usage *models.OrganizationUsage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := repository.UsageKey{OrganizationID: usage.OrganizationID, Month: usage.Month}
+	row := r.rows[key]
+	row.OrganizationID, row.Month = key.OrganizationID, key.Month
+	for _, metric := range models.UsageMetrics {
+		if usage.Get(metric) > row.Get(metric) {
+			row.Set(metric, usage.Get(metric))
+		}
+	}
+	r.rows[key] = row
+	return nil
+}
+
+func (r *memoryUsageRepository) Reconcile(_ context.Context, usage *models.OrganizationUsage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rows[repository.UsageKey{OrganizationID: usage.OrganizationID, Month: usage.Month}] = *usage
+	return nil
+}
+
+func (r *memoryUsageRepository) Subtract(_ context.Context, key repository.UsageKey, metric models.UsageMetric, quantity int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	row, ok := r.rows[key]
+	if !ok {
+		return nil
+	}
+	row.Set(metric, max(row.Get(metric)-quantity, 0))
+	r.rows[key] = row
+	return nil
+}
+
+func (r *memoryUsageRepository) Organizations(_ context.Context, month string, start, end time.Time) ([]uuid.UUID, error) {
+	found := make(map[uuid.UUID]bool)
+	r.mu.Lock()
+	for key := range r.rows {
+		if key.Month == month {
+			found[key.OrganizationID] = true
+		}
+	}
+	r.mu.Unlock()
+	r.forms.mu.Lock()
+	for _, form := range r.forms.forms {
+		if !form.CreatedAt.Before(start) && form.CreatedAt.Before(end) {
+			found[form.OrganizationID] = true
+		}
+	}
+	r.forms.mu.Unlock()
+
+	ids := make([]uuid.UUID, 0, len(found))
+	for id := range found {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (r *memoryUsageRepository) Actual(_ context.Context, orgID uuid.UUID, start, end time.Time) (*models.OrganizationUsage, error) {
+	r.forms.mu.Lock()
+	defer r.forms.mu.Unlock()
+	usage := &models.OrganizationUsage{OrganizationID: orgID, Month: models.UsageMonth(start)}
+	for _, form := range r.forms.forms {
+		if form.OrganizationID == orgID && !form.CreatedAt.Before(start) && form.CreatedAt.Before(end) {
+			usage.FormsCreated++
+		}
+	}
+	return usage, nil
+}
+
+func (r *memoryUsageRepository) FormIDs(_ context.Context, orgID uuid.UUID) ([]string, error) {
+	r.forms.mu.Lock()
+	defer r.forms.mu.Unlock()
+	var ids []string
+	for _, form := range r.forms.forms {
+		if form.OrganizationID == orgID {
+			ids = append(ids, form.ID.String())
+		}
+	}
+	return ids, nil
+}
+
+func (r *memoryUsageRepository) CountActiveForms(_ context.Context, orgID uuid.UUID) (int64, error) {
+	r.forms.mu.Lock()
+	defer r.forms.mu.Unlock()
+	var count int64
+	for _, form := range r.forms.forms {
+		if form.OrganizationID == orgID && form.Status != models.FormStatusClosed && !form.DeletedAt.Valid {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// fixedResponseCount answers CountResponses with n
+type fixedResponseCount int64
+
+func (n fixedResponseCount) CountResponses(context.Context, []string, time.Time, time.Time) (int64, error) {
+	return int64(n), nil
+}
+
+type usageFixture struct {
+	*memoryStore
+	counter *memoryUsageCounter
+	usage   *memoryUsageRepository
+	meter   *UsageMeter
+	formSvc FormService
+	svc     UsageService
+}
+
+func newUsageFixture(t *testing.T, responses fixedResponseCount) *usageFixture {
+	t.Helper()
+	f := &usageFixture{memoryStore: newMemoryStore(time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))}
+	f.counter = newMemoryUsageCounter()
+	f.usage = newMemoryUsageRepository(f.forms)
+	f.meter = NewUsageMeter(f.counter, f.usage, f.forms, f.orgs, map[string]models.Plan{
+		models.DefaultPlan: {MaxActiveForms: 2, MaxResponsesPerMonth: 2},
+	}, true)
+	f.meter.now = f.clock.now
+	f.formSvc = NewFormService(f.forms, f.questions, nil, f.orgs, events.LogPublisher{}, events.LogAuditor{}, nil, nil, f.meter, nil)
+	f.svc = NewUsageService(f.meter, f.forms, f.orgs, responses, nil, ExportLimits{MaxConcurrentJobs: 4, MaxJobsPerOrganization: 2})
+	return f
+}
+
+func TestUsageQuotas(t *testing.T) {
+	ctx := context.Background()
+	f := newUsageFixture(t, 0)
+	owner := uuid.New()
+
+	var forms []*models.Form
+	for i := 0; i < 2; i++ {
+		form, err := f.formSvc.CreateForm(ctx, owner, CreateFormRequest{Title: "Survey"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		forms = append(forms, form)
+	}
+	if _, err := f.formSvc.CreateForm(ctx, owner, CreateFormRequest{Title: "One too many"}); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("third form: err = %v, want ErrQuotaExceeded", err)
+	}
+
+	// Closing a form makes room for another
+	closed := forms[0]
+	closed.Status = models.FormStatusClosed
+	if err := f.forms.Update(ctx, closed); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.formSvc.CreateForm(ctx, owner, CreateFormRequest{Title: "Replacement"}); err != nil {
+		t.Fatalf("form after closing one: %v", err)
+	}
+
+	// A retried response is admitted without being counted twice
+	form := forms[1]
+	for _, responseID := range []string{"r1", "r1", "r2"} {
+		if err := f.svc.AdmitResponse(ctx, form.ID, responseID); err != nil {
+			t.Fatalf("admit %s: %v", responseID, err)
+		}
+	}
+	if err := f.svc.AdmitResponse(ctx, form.ID, "r3"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("third response: err = %v, want ErrQuotaExceeded", err)
+	}
+
+	report, err := f.svc.GetOwnUsage(ctx, owner, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.FormsCreated != 3 || report.Responses != 2 || report.ActiveForms != 2 || report.Plan.Name != models.DefaultPlan {
+		t.Errorf("usage = %+v, want 3 forms created, 2 responses and 2 active forms on the free plan", report)
+	}
+
+	// Without enforcement, usage is still metered
+	f.meter.enforce = false
+	if err := f.svc.AdmitResponse(ctx, form.ID, "r3"); err != nil {
+		t.Errorf("admit without quotas: %v", err)
+	}
+	if usage, _ := f.counter.Get(ctx, UsageKeyOf(form.OrganizationID, f.clock.now())); usage.Responses != 3 {
+		t.Errorf("responses counted = %d, want 3", usage.Responses)
+	}
+}
+
+func TestUsageReportRequiresOrganizationAdmin(t *testing.T) {
+	ctx := context.Background()
+	f := newUsageFixture(t, 0)
+	owner, member := uuid.New(), uuid.New()
+
+	org := &models.Organization{Name: "Acme"}
+	if err := f.orgs.Create(ctx, org, &models.OrganizationMember{UserID: owner, Role: models.OrganizationRoleOwner}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.orgs.AddMember(ctx, &models.OrganizationMember{OrganizationID: org.ID, UserID: member, Role: models.OrganizationRoleMember}); err != nil {
+		t.Fatal(err)
+	}
+
+	inOrg := tenant.WithOrganization(ctx, org.ID)
+	if _, err := f.svc.GetOwnUsage(inOrg, member, ""); !errors.Is(err, ErrInsufficientOrganizationRole) {
+		t.Errorf("usage for a member: err = %v, want ErrInsufficientOrganizationRole", err)
+	}
+	if _, err := f.svc.GetOwnUsage(inOrg, owner, ""); err != nil {
+		t.Errorf("usage for the owner: %v", err)
+	}
+	if _, err := f.svc.GetUsage(ctx, org.ID, "October"); !errors.Is(err, ErrInvalidUsageMonth) {
+		t.Errorf("usage of an invalid month: err = %v, want ErrInvalidUsageMonth", err)
+	}
+}
+
+func TestLimitsReportedToMembers(t *testing.T) {
+	ctx := context.Background()
+	f := newUsageFixture(t, 0)
+	owner, member := uuid.New(), uuid.New()
+
+	org := &models.Organization{Name: "Acme"}
+	if err := f.orgs.Create(ctx, org, &models.OrganizationMember{UserID: owner, Role: models.OrganizationRoleOwner}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.orgs.AddMember(ctx, &models.OrganizationMember{OrganizationID: org.ID, UserID: member, Role: models.OrganizationRoleMember}); err != nil {
+		t.Fatal(err)
+	}
+	inOrg := tenant.WithOrganization(ctx, org.ID)
+	if _, err := f.formSvc.CreateForm(inOrg, owner, CreateFormRequest{Title: "Survey"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Members who can't see the usage report still see the quotas
+	limits, err := f.svc.GetLimits(inOrg, member)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limits.OrganizationID != org.ID || limits.Plan.MaxActiveForms != 2 || limits.ActiveForms != 1 || limits.Exports.MaxJobsPerOrganization != 2 {
+		t.Errorf("limits = %+v, want the plan, usage and export bounds of the organization", limits)
+	}
+
+	// Acting in no organization, the personal organization is reported
+	personal, err := f.svc.GetLimits(ctx, member)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if personal.OrganizationID == org.ID || personal.ActiveForms != 0 {
+		t.Errorf("personal limits = %+v, want the personal organization", personal)
+	}
+
+	if _, err := f.svc.GetLimits(inOrg, uuid.New()); !errors.Is(err, ErrOrganizationNotFound) {
+		t.Errorf("limits for a stranger: err = %v, want ErrOrganizationNotFound", err)
+	}
+}
+
+func TestUsageFlushAndReconcile(t *testing.T) {
+	ctx := context.Background()
+	f := newUsageFixture(t, 5)
+	owner := uuid.New()
+
+	form, err := f.formSvc.CreateForm(ctx, owner, CreateFormRequest{Title: "Survey"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.svc.AdmitResponse(ctx, form.ID, "r1"); err != nil {
+		t.Fatal(err)
+	}
+	key := UsageKeyOf(form.OrganizationID, f.clock.now())
+
+	if flushed, err := f.meter.Flush(ctx); err != nil || flushed != 1 {
+		t.Fatalf("Flush() = %d, %v; want 1 organization month", flushed, err)
+	}
+	if stored, _ := f.usage.Get(ctx, key); stored.FormsCreated != 1 || stored.Responses != 1 {
+		t.Errorf("flushed usage = %+v, want 1 form and 1 response", stored)
+	}
+
+	// A counter restarted from zero never lowers the counts stored
+	f.counter.counts = make(map[repository.UsageKey]models.OrganizationUsage)
+	f.counter.dirty[key] = true
+	if _, err := f.meter.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := f.usage.Get(ctx, key); stored.FormsCreated != 1 {
+		t.Errorf("forms created after flushing a restarted counter = %d, want 1", stored.FormsCreated)
+	}
+
+	// The projection counted responses the meter missed
+	result, err := f.svc.Reconcile(ctx, key.Month)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Organizations != 1 || result.Drifted != 1 {
+		t.Errorf("Reconcile() = %+v, want 1 organization drifted", result)
+	}
+	stored, _ := f.usage.Get(ctx, key)
+	if stored.Responses != 5 || stored.FormsCreated != 1 || stored.ReconciledAt == nil {
+		t.Errorf("reconciled usage = %+v, want 5 responses and 1 form", stored)
+	}
+	if counted, _ := f.counter.Get(ctx, key); counted.Responses != 5 {
+		t.Errorf("counter after reconciling = %d responses, want 5", counted.Responses)
+	}
+
+	// Reconciling again finds nothing to correct
+	if result, err := f.svc.Reconcile(ctx, key.Month); err != nil || result.Drifted != 0 {
+		t.Errorf("second Reconcile() = %+v, %v; want no drift", result, err)
+	}
+}
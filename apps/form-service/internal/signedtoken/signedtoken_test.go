@@ -0,0 +1,58 @@
+package signedtoken
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type testClaims struct {
+	Subject   string `json:"sub"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+func TestSignVerify(t *testing.T) {
+	claims := testClaims{Subject: "form-1", ExpiresAt: 1700000300}
+
+	token, err := Sign("secret", claims)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	got, err := Verify[testClaims]("secret", token)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if got != claims {
+		t.Errorf("claims = %+v, want %+v", got, claims)
+	}
+
+	// Claims that don't decode are refused even when signed
+	notJSON := base64.RawURLEncoding.EncodeToString([]byte("form-1"))
+	notJSON += "." + signature("secret", notJSON)
+	_, sig, _ := strings.Cut(token, ".")
+	otherClaims, _ := Sign("secret", testClaims{Subject: "form-2", ExpiresAt: claims.ExpiresAt})
+	encoded, _, _ := strings.Cut(otherClaims, ".")
+
+	for name, token := range map[string]string{
+		"other secret":       mustSign(t, "other", claims),
+		"swapped claims":     encoded + "." + sig,
+		"claims not in JSON": notJSON,
+		"malformed":          "not-a-token",
+		"empty":              "",
+	} {
+		got, err := Verify[testClaims]("secret", token)
+		if !errors.Is(err, ErrInvalidToken) || got != (testClaims{}) {
+			t.Errorf("%s: Verify = %+v, %v; want ErrInvalidToken", name, got, err)
+		}
+	}
+}
+
+func mustSign(t *testing.T, secret string, claims testClaims) string {
+	t.Helper()
+	token, err := Sign(secret, claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
@@ -0,0 +1,29 @@
+// Package tenant carries the organization a request acts in. The gateway
+// takes it from the org_id claim of the caller's token and passes it in the
+// X-Organization-ID header. Requests of tokens without the claim act in no
+// organization; they keep the access they had before organizations existed
+// until every token carries one.
+package tenant
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Header is the header the gateway passes the organization of the caller in
+const Header = "X-Organization-ID"
+
+// organizationKey carries the organization of a request in its context
+type organizationKey struct{}
+
+// WithOrganization returns ctx acting in the organization orgID
+func WithOrganization(ctx context.Context, orgID uuid.UUID) context.Context {
+	return context.WithValue(ctx, organizationKey{}, orgID)
+}
+
+// Organization returns the organization ctx acts in, if any
+func Organization(ctx context.Context) (uuid.UUID, bool) {
+	orgID, ok := ctx.Value(organizationKey{}).(uuid.UUID)
+	return orgID, ok && orgID != uuid.Nil
+}
@@ -0,0 +1,63 @@
+package testutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/challenge"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/events"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/repository"
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/service"
+)
+
+// App is the form service wired as the server wires it, against the
+// dependencies of one test
+type App struct {
+	DB     *gorm.DB
+	Redis  *redis.Client
+	Events *EventBus
+
+	Forms         service.FormService
+	Collaborators service.CollaboratorService
+	Activity      service.ActivityService
+	Drafts        service.DraftService
+	UsageMeter    *service.UsageMeter
+	// Definitions caches the definitions of published forms in Redis
+	Definitions *service.DefinitionCache
+}
+
+// NewApp wires the form service to a new schema, Redis database and event
+// bus. Quotas are not enforced.
+func NewApp(t *testing.T) *App {
+	t.Helper()
+	db := Postgres(t)
+	redisClient := Redis(t)
+	bus := NewEventBus(t)
+
+	formRepo := repository.NewFormRepository(db)
+	questionRepo := repository.NewQuestionRepository(db)
+	collaboratorRepo := repository.NewCollaboratorRepository(db)
+	orgRepo := repository.NewOrganizationRepository(db)
+
+	publisher := events.NewPublisher(bus.URL)
+	auditor := events.NewAuditor(bus.URL, "audit-log", 100, prometheus.NewRegistry())
+	activityLog := service.NewActivityLog(0, events.NewRedisActivityPublisher(redisClient))
+	usageMeter := service.NewUsageMeter(repository.NewRedisUsageCounter(redisClient), repository.NewUsageRepository(db), formRepo, orgRepo, nil, false)
+	definitions := service.NewDefinitionCache(repository.NewRedisDefinitionCache(redisClient), time.Minute, nil)
+
+	return &App{
+		DB:            db,
+		Redis:         redisClient,
+		Events:        bus,
+		Forms:         service.NewFormService(formRepo, questionRepo, collaboratorRepo, orgRepo, publisher, auditor, challenge.NewIssuer(challenge.Config{}), activityLog, usageMeter, definitions),
+		Collaborators: service.NewCollaboratorService(formRepo, collaboratorRepo, orgRepo, auditor),
+		Activity:      service.NewActivityService(repository.NewActivityRepository(db), formRepo, collaboratorRepo, orgRepo),
+		Drafts:        service.NewDraftService(formRepo, repository.NewRedisDraftCache(redisClient), repository.NewDraftRepository(db), 0),
+		UsageMeter:    usageMeter,
+		Definitions:   definitions,
+	}
+}
@@ -0,0 +1,67 @@
+package testutil
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// errNoDocker is returned when a dependency is neither configured nor can be
+// started in a container
+var errNoDocker = errors.New("docker is not available")
+
+var (
+	containersMu sync.Mutex
+	containers   []string
+)
+
+// startContainer runs image detached, with port published on a random
+// loopback port, and returns the address the port is reachable at. The
+// container is removed once stopped by stopContainers.
+func startContainer(image, port string, env ...string) (string, error) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return "", errNoDocker
+	}
+
+	args := []string{"run", "--detach", "--rm", "--publish", "127.0.0.1::" + port}
+	for _, e := range env {
+		args = append(args, "--env", e)
+	}
+	out, err := exec.Command("docker", append(args, image)...).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to start %s: %w", image, commandError(err))
+	}
+	id := strings.TrimSpace(string(out))
+	containersMu.Lock()
+	containers = append(containers, id)
+	containersMu.Unlock()
+
+	out, err = exec.Command("docker", "port", id, port+"/tcp").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the port of %s: %w", image, commandError(err))
+	}
+	// One line per address the port is published on
+	addr, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	return addr, nil
+}
+
+// stopContainers stops the containers started by the tests
+func stopContainers() {
+	containersMu.Lock()
+	defer containersMu.Unlock()
+	if len(containers) > 0 {
+		exec.Command("docker", append([]string{"stop", "--time", "1"}, containers...)...).Run()
+	}
+	containers = nil
+}
+
+// commandError adds the standard error of a failed command to err
+func commandError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return err
+}
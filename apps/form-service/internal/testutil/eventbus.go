@@ -0,0 +1,84 @@
+package testutil
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Event is an event received by the event bus
+type Event struct {
+	EventType string                 `json:"event_type"`
+	Source    string                 `json:"source"`
+	Key       string                 `json:"key"`
+	Data      map[string]interface{} `json:"data"`
+}
+
+// EventBus stands in for the event bus service, recording the events posted
+// to its /events endpoint as it would produce them to Kafka
+type EventBus struct {
+	URL string
+
+	mu     sync.Mutex
+	events []Event
+}
+
+// NewEventBus starts an event bus, stopped when the test ends
+func NewEventBus(t *testing.T) *EventBus {
+	t.Helper()
+	bus := &EventBus{}
+	server := httptest.NewServer(http.HandlerFunc(bus.serveHTTP))
+	t.Cleanup(server.Close)
+	bus.URL = server.URL
+	return bus
+}
+
+func (b *EventBus) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/events" {
+		http.NotFound(w, r)
+		return
+	}
+	var event Event
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil || event.EventType == "" {
+		http.Error(w, "invalid event", http.StatusBadRequest)
+		return
+	}
+	b.mu.Lock()
+	b.events = append(b.events, event)
+	b.mu.Unlock()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Events returns the events of eventType received so far
+func (b *EventBus) Events(eventType string) []Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var events []Event
+	for _, event := range b.events {
+		if event.EventType == eventType {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// WaitFor returns the events of eventType once n were received, failing the
+// test when they are not within timeout. Events published asynchronously,
+// such as audit events, arrive after the call publishing them returned.
+func (b *EventBus) WaitFor(t *testing.T, eventType string, n int, timeout time.Duration) []Event {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for {
+		events := b.Events(eventType)
+		if len(events) >= n {
+			return events
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("received %d %s events within %v, want %d", len(events), eventType, timeout, n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,121 @@
+package testutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/Mir00r/X-Form-Backend/services/form-service/internal/database"
+)
+
+// postgresImage is the PostgreSQL started when no database is configured
+const postgresImage = "postgres:16-alpine"
+
+// schemaSeq numbers the schemas created by the tests of the binary
+var schemaSeq atomic.Int64
+
+// postgresServer is the database the schemas are created in, connected to
+// once per test binary
+var postgresServer = sync.OnceValues(func() (*gorm.DB, error) {
+	dsn := os.Getenv("FORM_SERVICE_TEST_DATABASE_URL")
+	if dsn == "" {
+		addr, err := startContainer(postgresImage, "5432",
+			"POSTGRES_USER=xform", "POSTGRES_PASSWORD=xform", "POSTGRES_DB=xform")
+		if err != nil {
+			return nil, err
+		}
+		dsn = fmt.Sprintf("postgres://xform:xform@%s/xform?sslmode=disable", addr)
+	}
+
+	db, err := openPostgres(dsn)
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	// A container accepts connections once initialized
+	err = waitFor(30*time.Second, func(ctx context.Context) error { return sqlDB.PingContext(ctx) })
+	if err != nil {
+		return nil, fmt.Errorf("PostgreSQL at %s is not reachable: %w", redactURL(dsn), err)
+	}
+	return db, nil
+})
+
+// Postgres returns a connection to a new schema of the test database, with
+// every migration applied. The schema is dropped when the test ends.
+func Postgres(t *testing.T) *gorm.DB {
+	t.Helper()
+	Integration(t)
+	server, err := postgresServer()
+	if errors.Is(err, errNoDocker) {
+		t.Skip("FORM_SERVICE_TEST_DATABASE_URL not set and docker is not available")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema := fmt.Sprintf("integration_%d_%d", os.Getpid(), schemaSeq.Add(1))
+	if err := server.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	// Every connection of the pool uses the schema
+	u, err := url.Parse(server.Dialector.(*postgres.Dialector).DSN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+
+	db, err := openPostgres(u.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := database.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func openPostgres(dsn string) (*gorm.DB, error) {
+	return gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard, DisableAutomaticPing: true})
+}
+
+// waitFor retries check until it succeeds or timeout passes
+func waitFor(timeout time.Duration, check func(ctx context.Context) error) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		err := check(ctx)
+		cancel()
+		if err == nil || time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+// redactURL hides the password of a connection URL
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "the configured URL"
+	}
+	return u.Redacted()
+}
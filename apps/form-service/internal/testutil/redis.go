@@ -0,0 +1,80 @@
+package testutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisImage is the Redis started when no Redis is configured
+const redisImage = "redis:7-alpine"
+
+// redisDatabases are the logical databases leased to the tests, one at a
+// time each. Database 0 is left alone, for the data of developers pointing
+// the tests at their own Redis.
+var redisDatabases = func() chan int {
+	databases := make(chan int, 15)
+	for db := 1; db <= 15; db++ {
+		databases <- db
+	}
+	return databases
+}()
+
+// redisServer returns the options of the test Redis, reached once per test
+// binary
+var redisServer = sync.OnceValues(func() (*redis.Options, error) {
+	redisURL := os.Getenv("FORM_SERVICE_TEST_REDIS_URL")
+	if redisURL == "" {
+		addr, err := startContainer(redisImage, "6379")
+		if err != nil {
+			return nil, err
+		}
+		redisURL = "redis://" + addr
+	}
+
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, err
+	}
+	client := redis.NewClient(opts)
+	defer client.Close()
+	err = waitFor(15*time.Second, func(ctx context.Context) error { return client.Ping(ctx).Err() })
+	if err != nil {
+		return nil, fmt.Errorf("Redis at %s is not reachable: %w", redactURL(redisURL), err)
+	}
+	return opts, nil
+})
+
+// Redis returns a client of an empty database of the test Redis, leased to
+// the test until it ends. Tests past the number of databases wait for one.
+func Redis(t *testing.T) *redis.Client {
+	t.Helper()
+	Integration(t)
+	server, err := redisServer()
+	if errors.Is(err, errNoDocker) {
+		t.Skip("FORM_SERVICE_TEST_REDIS_URL not set and docker is not available")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := *server
+	opts.DB = <-redisDatabases
+	client := redis.NewClient(&opts)
+	if err := client.FlushDB(context.Background()).Err(); err != nil {
+		redisDatabases <- opts.DB
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		client.FlushDB(context.Background())
+		client.Close()
+		redisDatabases <- opts.DB
+	})
+	return client
+}
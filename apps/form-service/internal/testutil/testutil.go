@@ -0,0 +1,32 @@
+// Package testutil runs integration tests of the form service against real
+// dependencies: PostgreSQL, Redis and an event bus recording what the
+// service publishes. Each test gets its own schema, Redis database and event
+// bus, so tests run in parallel.
+//
+// The dependencies are the services at FORM_SERVICE_TEST_DATABASE_URL and
+// FORM_SERVICE_TEST_REDIS_URL, as CI provides them. Otherwise they are
+// started once per test binary in Docker containers, stopped by Run. Without
+// either, and with -short, the integration tests are skipped.
+package testutil
+
+import (
+	"testing"
+)
+
+// Run runs the tests of a package using the harness and stops the
+// containers they started. Call it from TestMain:
+//
+//	func TestMain(m *testing.M) { os.Exit(testutil.Run(m)) }
+func Run(m *testing.M) int {
+	code := m.Run()
+	stopContainers()
+	return code
+}
+
+// Integration marks t as an integration test, skipped with -short
+func Integration(t *testing.T) {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("integration test skipped with -short")
+	}
+}
@@ -166,6 +166,38 @@ class ConfigurationManager {
         corsOrigins: this.getEnvArray('CORS_ORIGINS', ['http://localhost:3000'])
       },
 
+      // Spam protection of public submissions
+      spamProtection: {
+        captchaProvider: this.getEnvString('CAPTCHA_PROVIDER'), // recaptcha, hcaptcha or turnstile
+        captchaSecretKey: this.getEnvString('CAPTCHA_SECRET_KEY'),
+        captchaTimeout: this.getEnvNumber('CAPTCHA_TIMEOUT_MS', 3000),
+        captchaErrorCooldown: this.getEnvNumber('CAPTCHA_ERROR_COOLDOWN_MS', 30000),
+        recaptchaMinScore: parseFloat(this.getEnvString('RECAPTCHA_MIN_SCORE', '0.5')),
+        // Shared with the form service, which signs the honeypot challenge tokens
+        challengeSecret: this.getEnvString('SUBMISSION_CHALLENGE_SECRET', this.getEnvString('JWT_SECRET', 'your-jwt-secret-key')),
+        honeypotMinFillSeconds: this.getEnvNumber('HONEYPOT_MIN_FILL_SECONDS', 3),
+        challengeMaxAgeSeconds: this.getEnvNumber('SUBMISSION_CHALLENGE_MAX_AGE_SECONDS', 24 * 60 * 60)
+      },
+
+      // Deduplication of identical submissions, such as double clicks on submit
+      deduplication: {
+        enabled: this.getEnvBoolean('DEDUP_ENABLED', false),
+        windowSeconds: this.getEnvNumber('DEDUP_WINDOW_SECONDS', 30),
+        redisTimeout: this.getEnvNumber('DEDUP_REDIS_TIMEOUT_MS', 250)
+      },
+
+      // Purge of responses older than the retention of their form
+      retention: {
+        enabled: this.getEnvBoolean('RETENTION_ENABLED', true),
+        mode: this.getEnvString('RETENTION_MODE', 'anonymize'), // anonymize, delete
+        runAt: this.getEnvString('RETENTION_RUN_AT', '03:00'), // HH:MM, UTC
+        dryRun: this.getEnvBoolean('RETENTION_DRY_RUN', false),
+        batchSize: this.getEnvNumber('RETENTION_BATCH_SIZE', 500),
+        batchDelayMs: this.getEnvNumber('RETENTION_BATCH_DELAY_MS', 200),
+        maxPerRun: this.getEnvNumber('RETENTION_MAX_PER_RUN', 0), // 0 is unlimited
+        lockTtlMs: this.getEnvNumber('RETENTION_LOCK_TTL_MS', 60000)
+      },
+
       // Cache Configuration
       cache: {
         enabled: this.getEnvBoolean('CACHE_ENABLED', true),
@@ -242,6 +274,33 @@ class ConfigurationManager {
       if (this.config.security.sessionSecret === 'session-secret-change-in-production') {
         errors.push('SESSION_SECRET must be set to a secure value in production');
       }
+
+      if (this.config.spamProtection.challengeSecret === 'your-jwt-secret-key') {
+        errors.push('SUBMISSION_CHALLENGE_SECRET must be set to a secure value in production');
+      }
+    }
+
+    if (this.config.deduplication.enabled && this.config.deduplication.windowSeconds <= 0) {
+      errors.push('DEDUP_WINDOW_SECONDS must be greater than 0 when deduplication is enabled');
+    }
+
+    const { retention } = this.config;
+    if (retention.enabled) {
+      if (!['anonymize', 'delete'].includes(retention.mode)) {
+        errors.push('RETENTION_MODE must be one of: anonymize, delete');
+      }
+      if (!/^([01]\d|2[0-3]):[0-5]\d$/.test(retention.runAt)) {
+        errors.push('RETENTION_RUN_AT must be a UTC time as HH:MM');
+      }
+      if (retention.batchSize <= 0) {
+        errors.push('RETENTION_BATCH_SIZE must be greater than 0');
+      }
+      if (retention.batchDelayMs < 0 || retention.maxPerRun < 0) {
+        errors.push('RETENTION_BATCH_DELAY_MS and RETENTION_MAX_PER_RUN must not be negative');
+      }
+      if (retention.lockTtlMs < 1000) {
+        errors.push('RETENTION_LOCK_TTL_MS must be at least 1000');
+      }
     }
 
     // Validate port ranges
@@ -259,6 +318,17 @@ class ConfigurationManager {
       errors.push('RATE_LIMIT_MAX must be greater than 0');
     }
 
+    // Validate spam protection
+    const { captchaProvider, captchaSecretKey, recaptchaMinScore } = this.config.spamProtection;
+    if (captchaProvider && !['recaptcha', 'hcaptcha', 'turnstile'].includes(captchaProvider)) {
+      errors.push('CAPTCHA_PROVIDER must be one of: recaptcha, hcaptcha, turnstile');
+    } else if (captchaProvider && !captchaSecretKey) {
+      errors.push('CAPTCHA_SECRET_KEY is required with CAPTCHA_PROVIDER');
+    }
+    if (!(recaptchaMinScore >= 0 && recaptchaMinScore <= 1)) {
+      errors.push('RECAPTCHA_MIN_SCORE must be between 0 and 1');
+    }
+
     // Validate logging configuration
     const validLogLevels = ['error', 'warn', 'info', 'debug'];
     if (!validLogLevels.includes(this.config.logging.level)) {
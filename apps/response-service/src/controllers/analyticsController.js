@@ -33,9 +33,9 @@ const getFormAnalytics = async (req, res) => {
       throw new NotFoundError('Form not found');
     }
 
-    // Get all responses for this form
+    // Get all responses for this form; test responses are left out
     const formResponses = Array.from(mockDatabase.responses.values())
-      .filter(response => response.formId === formId);
+      .filter(response => response.formId === formId && !response.isTest);
 
     // Calculate basic statistics
     const totalResponses = formResponses.length;
@@ -378,7 +378,8 @@ const getDashboardAnalytics = async (req, res) => {
   try {
     logger.info('Generating dashboard analytics', { correlationId });
 
-    const allResponses = Array.from(mockDatabase.responses.values());
+    const allResponses = Array.from(mockDatabase.responses.values())
+      .filter(response => !response.isTest);
     const allForms = Array.from(mockDatabase.forms.values());
 
     // Calculate overall statistics
@@ -0,0 +1,130 @@
+/**
+ * Internal Controller for Response Service
+ * Endpoints called by other services with the API key, not routed by the
+ * gateway. The form service selects and deletes responses in bulk through
+ * them; the gateway reads the response totals of its admin dashboard.
+ */
+
+const { createSuccessResponse } = require('../dto/response-dtos');
+const { createError } = require('../middleware/errorHandler');
+const logger = require('../utils/logger');
+const { store } = require('./responseController');
+
+// Response IDs deleted in one call, the batch size of the form service
+const MAX_DELETE_IDS = 1000;
+
+/**
+ * Whether a response matches the filter of a bulk deletion: every filter
+ * set must match
+ * @param {Object} response - Response
+ * @param {Object} filter - submitted_before, submitted_after, response_ids
+ *   and test_only
+ * @param {Set<string>|null} ids - The response IDs of the filter
+ * @returns {boolean}
+ */
+const matchesFilter = (response, filter, ids) => {
+  const submittedAt = new Date(response.submittedAt);
+  if (filter.submitted_before && !(submittedAt < new Date(filter.submitted_before))) {
+    return false;
+  }
+  if (filter.submitted_after && !(submittedAt > new Date(filter.submitted_after))) {
+    return false;
+  }
+  if (ids && !ids.has(response.id)) {
+    return false;
+  }
+  if (filter.test_only && response.isTest !== true) {
+    return false;
+  }
+  return true;
+};
+
+/**
+ * Count the responses of a form matching a filter, returning up to limit
+ * of them, oldest first
+ */
+const matchResponses = async (req, res) => {
+  const correlationId = req.headers['x-correlation-id'] || req.correlationId;
+  const { formId } = req.params;
+  const { filter = {}, limit = 0 } = req.body || {};
+
+  if (typeof filter !== 'object' || filter === null || !Number.isInteger(limit) || limit < 0) {
+    throw createError('filter must be an object and limit a non-negative integer', 400, 'VALIDATION_ERROR');
+  }
+  const ids = Array.isArray(filter.response_ids) && filter.response_ids.length > 0
+    ? new Set(filter.response_ids)
+    : null;
+
+  const responses = Array.from(store.responses.values()).filter(response => response.formId === formId);
+  const matched = responses
+    .filter(response => matchesFilter(response, filter, ids))
+    .sort((a, b) => new Date(a.submittedAt) - new Date(b.submittedAt));
+
+  res.json(createSuccessResponse({
+    matched: matched.length,
+    total: responses.length,
+    responses: matched.slice(0, limit).map(response => ({
+      id: response.id,
+      submitted_at: response.submittedAt,
+      test: response.isTest === true
+    }))
+  }, 'Responses matched', correlationId));
+};
+
+/**
+ * Delete responses of a form by ID, with their revisions and the records of
+ * their respondents. IDs deleted already, or of other forms, are skipped.
+ */
+const deleteResponses = async (req, res) => {
+  const correlationId = req.headers['x-correlation-id'] || req.correlationId;
+  const { formId } = req.params;
+  const { response_ids: responseIds } = req.body || {};
+
+  if (!Array.isArray(responseIds) || responseIds.length > MAX_DELETE_IDS) {
+    throw createError(`response_ids must be an array of at most ${MAX_DELETE_IDS} IDs`, 400, 'VALIDATION_ERROR');
+  }
+
+  let deleted = 0;
+  for (const id of responseIds) {
+    const response = store.responses.get(id);
+    if (!response || response.formId !== formId) {
+      continue;
+    }
+    store.responses.delete(id);
+    // Test responses never hold the one response of their submitter
+    if (!response.isTest) {
+      store.submitters.delete(`${response.formId}:${response.respondentId}`);
+    }
+    store.revisions.delete(id);
+    deleted++;
+  }
+
+  logger.logBusiness('RESPONSES_BULK_DELETED', {
+    correlationId,
+    formId,
+    requested: responseIds.length,
+    deleted
+  });
+
+  res.json(createSuccessResponse({ deleted }, 'Responses deleted', correlationId));
+};
+
+/**
+ * Report the total of submitted responses, drafts and test responses aside,
+ * in the { total } shape every service reports to the gateway
+ */
+const getStats = async (req, res) => {
+  let total = 0;
+  for (const response of store.responses.values()) {
+    if (!response.isDraft && !response.isTest) {
+      total++;
+    }
+  }
+  res.json({ total });
+};
+
+module.exports = {
+  matchResponses,
+  deleteResponses,
+  getStats
+};
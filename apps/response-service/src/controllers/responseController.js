@@ -4,13 +4,24 @@
  */
 
 const { createSuccessResponse, createErrorResponse } = require('../dto/response-dtos');
-const { NotFoundError, ValidationError, ForbiddenError, ConflictError } = require('../middleware/errorHandler');
+const { NotFoundError, ValidationError, ForbiddenError, ConflictError, createError } = require('../middleware/errorHandler');
 const logger = require('../utils/logger');
+const responseModes = require('../utils/responseModes');
+const responseEdits = require('../utils/responseEdits');
+const embedOrigins = require('../utils/embedOrigins');
+const spamProtection = require('../utils/spamProtection');
+const submissionDedup = require('../utils/submissionDedup');
+const config = require('../config/enhanced');
+const formServiceIntegration = require('../integrations/formService');
 
 // Mock database operations (replace with actual database integration)
 const mockDatabase = {
   responses: new Map(),
-  forms: new Map()
+  forms: new Map(),
+  // Unique index over formId + userId for one_per_user forms
+  submitters: new Set(),
+  // Earlier revisions of edited responses, keyed by response ID
+  revisions: new Map()
 };
 
 // Initialize with some mock data
@@ -18,18 +29,60 @@ mockDatabase.forms.set('f123e4567-e89b-12d3-a456-426614174000', {
   id: 'f123e4567-e89b-12d3-a456-426614174000',
   title: 'Customer Feedback Survey',
   status: 'active',
-  organizationId: 'org123'
+  organizationId: 'org123',
+  version: 1,
+  settings: {
+    response_mode: 'anonymous',
+    collect_metadata: true,
+    edit_window_minutes: 1440
+  }
 });
 
+/**
+ * Strip stored secrets from a response before it leaves the service
+ */
+const toPublicResponse = ({ editTokenHash, ...response }) => response;
+
+/**
+ * Answers of a response keyed by question ID, as the schema validators expect
+ */
+const answersByQuestion = (responses = []) =>
+  Object.fromEntries(responses.map(response => [response.questionId, response.value]));
+
+/**
+ * Whether a submission asks to be stored as a test response, with the
+ * X-Test-Submission header or the test flag of the body or query
+ */
+const isTestSubmission = (req) =>
+  req.get('X-Test-Submission') === 'true' || req.body.test === true || req.query.test === 'true';
+
 /**
  * Create a new form response
  */
 const createResponse = async (req, res) => {
   const correlationId = req.headers['x-correlation-id'] || req.correlationId;
   const startTime = Date.now();
+  // Deduplication claim of the submission, released if it fails to be stored
+  let dedupClaim = null;
   
   try {
     const { formId, respondentId, respondentEmail, respondentName, responses, metadata, isDraft, isPartial } = req.body;
+
+    // Drafts shared with preview tokens are for review only
+    if (req.body.previewToken) {
+      throw createError('Form previews are read-only; responses can only be submitted to the published form', 403, 'PREVIEW_READ_ONLY');
+    }
+
+    // Test submissions are left out of quotas, analytics, exports and
+    // notifications, so only the owner and collaborators may send them
+    const isTest = isTestSubmission(req);
+    if (isTest) {
+      const userId = responseModes.getAuthenticatedUserId(req.user);
+      if (!userId) {
+        throw createError('Test submissions require the owner or a collaborator of the form to be signed in', 401, 'AUTHENTICATION_REQUIRED');
+      }
+      await formServiceIntegration.authorizeTestSubmission(formId, userId, correlationId);
+    }
     
     logger.info('Creating new response', {
       correlationId,
@@ -37,7 +90,8 @@ const createResponse = async (req, res) => {
       respondentId,
       responseCount: responses?.length,
       isDraft,
-      isPartial
+      isPartial,
+      isTest
     });
 
     // Validate form exists and is active
@@ -52,17 +106,75 @@ const createResponse = async (req, res) => {
       });
     }
 
+    // Embedded forms only accept submissions from the sites allowed to embed them
+    embedOrigins.checkSubmissionOrigin(form.settings, req.get('Origin'), config.get('security.corsOrigins'));
+
+    // Drafts are autosaved; the challenge is passed once, when submitting
+    if (!isDraft) {
+      await spamProtection.checkSubmissionChallenge(form, req.body, { remoteIp: req.ip, correlationId });
+    }
+
+    // Enforce the form's response mode
+    const policy = responseModes.resolveResponsePolicy(form.settings);
+    const respondent = responseModes.resolveRespondent(policy, req.user);
+
     // Generate response ID
     const responseId = `r${Date.now()}_${Math.random().toString(36).substr(2, 9)}`;
+
+    // An identical submission within the deduplication window, such as a
+    // double click on submit, is answered with the response of the first.
+    // Nothing is stored or counted for it. Test submissions are repeated on
+    // purpose and never deduplicated.
+    if (!isDraft && !isTest && submissionDedup.isEnabled(form.settings)) {
+      const hash = submissionDedup.submissionHash(formId, responses, submissionDedup.respondentIdentity(
+        responseModes.getAuthenticatedUserId(req.user),
+        req.get('X-Respondent-Token'),
+        req.ip
+      ));
+      const originalId = await submissionDedup.claim(hash, responseId, { formId, correlationId });
+      if (originalId) {
+        const original = mockDatabase.responses.get(originalId);
+        logger.info('Duplicate submission answered with the original response', {
+          correlationId,
+          formId,
+          responseId: originalId
+        });
+        return res.status(200).json(
+          createSuccessResponse(
+            { ...(original ? toPublicResponse(original) : { id: originalId, formId }), isDuplicate: true },
+            'Duplicate submission; the original response is returned',
+            correlationId
+          )
+        );
+      }
+      dedupClaim = { hash, responseId };
+    }
+
+    const submitterKey = `${formId}:${respondent.submitterId}`;
+    const recordSubmitter = policy.oneResponsePerUser && !isTest;
+    if (recordSubmitter && mockDatabase.submitters.has(submitterKey)) {
+      throw responseModes.duplicateSubmissionError();
+    }
+
+    // Submissions count against the monthly response quota of the plan of
+    // the organization owning the form, test submissions aside
+    if (!isDraft && !isTest) {
+      await formServiceIntegration.admitResponse(formId, responseId, correlationId);
+    }
+
+    // Forms with an edit window hand out an edit token; only its hash is kept
+    const editToken = policy.editWindowMinutes && !isDraft ? responseEdits.issueEditToken() : null;
     
     // Create response object
     const newResponse = {
       id: responseId,
       formId,
       formTitle: form.title,
-      respondentId: respondentId || null,
-      respondentEmail: respondentEmail || null,
-      respondentName: respondentName || null,
+      // Identified modes store the authenticated user, never a client-supplied ID
+      respondentId: respondent.submitterId,
+      respondentEmail: respondent.isAnonymous ? null : respondentEmail || null,
+      respondentName: respondent.isAnonymous ? null : respondentName || null,
+      isAnonymous: respondent.isAnonymous,
       responses: responses.map(response => ({
         ...response,
         id: `resp_${Date.now()}_${Math.random().toString(36).substr(2, 6)}`
@@ -70,19 +182,35 @@ const createResponse = async (req, res) => {
       status: isDraft ? 'draft' : (isPartial ? 'partial' : 'completed'),
       isDraft: isDraft || false,
       isPartial: isPartial || false,
-      metadata: {
+      isTest,
+      metadata: responseModes.applyMetadataPolicy(policy, {
         ...metadata,
         submissionSource: 'api',
         ipAddress: req.ip,
         userAgent: req.get('User-Agent')
-      },
+      }),
       submittedAt: new Date().toISOString(),
       updatedAt: new Date().toISOString(),
-      version: 1
+      version: 1,
+      formVersion: form.version || 1,
+      revision: 1,
+      editTokenHash: editToken?.hash || null,
+      editableUntil: editToken ? responseEdits.editableUntil(policy) : null
     };
 
     // Store in mock database
     mockDatabase.responses.set(responseId, newResponse);
+    if (recordSubmitter) {
+      mockDatabase.submitters.add(submitterKey);
+    }
+
+    // The submitted response supersedes the respondent's autosaved draft
+    if (!isDraft) {
+      await formServiceIntegration.consumeDraft(formId, {
+        userId: responseModes.getAuthenticatedUserId(req.user),
+        respondentToken: req.get('X-Respondent-Token')
+      }, correlationId);
+    }
 
     const duration = Date.now() - startTime;
     
@@ -100,9 +228,10 @@ const createResponse = async (req, res) => {
       duration
     });
 
+    // The edit token is only ever returned here
     res.status(201).json(
       createSuccessResponse(
-        newResponse,
+        { ...toPublicResponse(newResponse), ...(editToken && { editToken: editToken.token }) },
         'Response submitted successfully',
         correlationId
       )
@@ -110,6 +239,10 @@ const createResponse = async (req, res) => {
 
   } catch (error) {
     const duration = Date.now() - startTime;
+
+    if (dedupClaim && !mockDatabase.responses.has(dedupClaim.responseId)) {
+      await submissionDedup.release(dedupClaim.hash, dedupClaim.responseId);
+    }
     
     logger.error('Failed to create response', {
       correlationId,
@@ -312,7 +445,7 @@ const getResponse = async (req, res) => {
 
     res.json(
       createSuccessResponse(
-        response,
+        toPublicResponse(response),
         'Response retrieved successfully',
         correlationId
       )
@@ -332,6 +465,76 @@ const getResponse = async (req, res) => {
   }
 };
 
+/**
+ * Download the PDF receipt of a submitted response. Respondents get theirs as
+ * the original submitter or with the edit token; admins and form managers
+ * get any, and other users those of the forms they may view.
+ */
+const getResponseReceipt = async (req, res) => {
+  const correlationId = req.headers['x-correlation-id'] || req.correlationId;
+  const { id } = req.params;
+  const startTime = Date.now();
+
+  try {
+    const response = mockDatabase.responses.get(id);
+    if (!response) {
+      throw new NotFoundError('Response not found');
+    }
+    if (response.status !== 'completed') {
+      throw createError('Receipts are only issued for submitted responses', 409, 'RESPONSE_NOT_SUBMITTED');
+    }
+
+    const userId = responseModes.getAuthenticatedUserId(req.user);
+    const userRole = req.user?.role;
+    const isRespondent = (userId && response.respondentId === userId) ||
+      responseEdits.holdsEditToken(response.editTokenHash, req.get(responseEdits.EDIT_TOKEN_HEADER));
+    if (!isRespondent && !userId) {
+      throw createError('Sign in or provide the edit token to download this receipt', 401, 'RECEIPT_CREDENTIALS_REQUIRED');
+    }
+
+    // Anyone but the respondent is checked by the form service against the
+    // form, unless they manage every form
+    const checkUser = !isRespondent && userRole !== 'admin' && userRole !== 'form_manager';
+    const receipt = await formServiceIntegration.renderReceipt(response.formId, {
+      responseId: response.id,
+      revision: response.revision,
+      submittedAt: response.submittedAt,
+      answers: answersByQuestion(response.responses),
+      locale: req.query.locale || response.metadata?.locale,
+      userId: checkUser ? userId : undefined
+    }, correlationId);
+
+    logger.info('Response receipt downloaded', {
+      correlationId,
+      responseId: id,
+      formId: response.formId,
+      revision: response.revision,
+      asRespondent: isRespondent,
+      duration: Date.now() - startTime
+    });
+
+    res.set({
+      'Content-Type': 'application/pdf',
+      'Content-Disposition': `attachment; filename="receipt-${id}.pdf"`,
+      'Cache-Control': 'private, no-store'
+    });
+    if (receipt.hash) {
+      res.set('X-Receipt-Hash', receipt.hash);
+    }
+    res.send(receipt.pdf);
+
+  } catch (error) {
+    logger.error('Failed to download response receipt', {
+      correlationId,
+      responseId: id,
+      error: error.message,
+      duration: Date.now() - startTime
+    });
+
+    throw error;
+  }
+};
+
 /**
  * Update an existing response
  */
@@ -351,39 +554,87 @@ const updateResponse = async (req, res) => {
       throw new NotFoundError('Response not found');
     }
 
-    // Check if response can be updated
-    if (existingResponse.status === 'completed' && req.user?.role !== 'admin') {
-      throw new ForbiddenError('Cannot update completed responses');
-    }
-
-    // Check authorization
     const userRole = req.user?.role;
     const userId = req.user?.id;
-    
-    if (userRole !== 'admin' && userRole !== 'form_manager') {
-      if (existingResponse.respondentId && existingResponse.respondentId !== userId) {
-        throw new ForbiddenError('Access denied to update this response');
+    const { responses, respondentEmail, respondentName, metadata, isDraft, isPartial } = req.body;
+
+    let updatedResponse;
+    let editor;
+
+    if (existingResponse.status === 'completed' && userRole !== 'admin') {
+      // Respondents may change the answers of a completed response during the
+      // form's edit window, as the original submitter or with the edit token
+      editor = responseEdits.authorizeEdit({
+        editableUntil: existingResponse.editableUntil,
+        editTokenHash: existingResponse.editTokenHash,
+        ownerId: existingResponse.respondentId
+      }, {
+        user: req.user,
+        editToken: req.get(responseEdits.EDIT_TOKEN_HEADER)
+      });
+
+      if (!responses) {
+        throw createError('Edits of a submitted response must include the answers', 400, 'VALIDATION_ERROR');
       }
-    }
 
-    const { responses, respondentEmail, respondentName, metadata, isDraft, isPartial } = req.body;
+      // Validate against the form version the response was submitted under
+      const form = mockDatabase.forms.get(existingResponse.formId);
+      if (form && (form.version || 1) !== existingResponse.formVersion) {
+        throw createError(
+          `Version ${existingResponse.formVersion} of the form is no longer available to validate the edit`,
+          409,
+          'FORM_VERSION_UNAVAILABLE'
+        );
+      }
+      await responseEdits.validateEditedAnswers(answersByQuestion(responses), form);
+
+      updatedResponse = {
+        ...existingResponse,
+        responses: responses.map(response => ({
+          ...response,
+          id: response.id || `resp_${Date.now()}_${Math.random().toString(36).substr(2, 6)}`
+        })),
+        updatedAt: new Date().toISOString(),
+        version: existingResponse.version + 1
+      };
+    } else {
+      // Check authorization
+      if (userRole !== 'admin' && userRole !== 'form_manager') {
+        if (!userId || (existingResponse.respondentId && existingResponse.respondentId !== userId)) {
+          throw new ForbiddenError('Access denied to update this response');
+        }
+      }
+      editor = {
+        editorId: responseModes.getAuthenticatedUserId(req.user),
+        method: userRole === 'admin' || userRole === 'form_manager' ? userRole : 'submitter'
+      };
+
+      // Update response
+      updatedResponse = {
+        ...existingResponse,
+        ...(responses && { responses: responses.map(response => ({
+          ...response,
+          id: response.id || `resp_${Date.now()}_${Math.random().toString(36).substr(2, 6)}`
+        })) }),
+        ...(respondentEmail !== undefined && { respondentEmail }),
+        ...(respondentName !== undefined && { respondentName }),
+        ...(metadata && { metadata: { ...existingResponse.metadata, ...metadata } }),
+        ...(isDraft !== undefined && { isDraft }),
+        ...(isPartial !== undefined && { isPartial }),
+        status: isDraft ? 'draft' : (isPartial ? 'partial' : 'completed'),
+        updatedAt: new Date().toISOString(),
+        version: existingResponse.version + 1
+      };
+    }
 
-    // Update response
-    const updatedResponse = {
-      ...existingResponse,
-      ...(responses && { responses: responses.map(response => ({
-        ...response,
-        id: response.id || `resp_${Date.now()}_${Math.random().toString(36).substr(2, 6)}`
-      })) }),
-      ...(respondentEmail !== undefined && { respondentEmail }),
-      ...(respondentName !== undefined && { respondentName }),
-      ...(metadata && { metadata: { ...existingResponse.metadata, ...metadata } }),
-      ...(isDraft !== undefined && { isDraft }),
-      ...(isPartial !== undefined && { isPartial }),
-      status: isDraft ? 'draft' : (isPartial ? 'partial' : 'completed'),
-      updatedAt: new Date().toISOString(),
-      version: existingResponse.version + 1
-    };
+    // Every change of answers keeps the replaced answers as a revision
+    if (responses) {
+      const editedAt = updatedResponse.updatedAt;
+      const revision = responseEdits.buildRevision(existingResponse, editor, editedAt);
+      mockDatabase.revisions.set(id, [...(mockDatabase.revisions.get(id) || []), revision]);
+      updatedResponse.revision = revision.revision + 1;
+      updatedResponse.lastEditedAt = editedAt;
+    }
 
     // Store updated response
     mockDatabase.responses.set(id, updatedResponse);
@@ -393,7 +644,9 @@ const updateResponse = async (req, res) => {
     logger.logBusiness('RESPONSE_UPDATED', 'response', id, {
       formId: updatedResponse.formId,
       status: updatedResponse.status,
-      version: updatedResponse.version
+      version: updatedResponse.version,
+      revision: updatedResponse.revision,
+      editMethod: editor.method
     }, { correlationId });
 
     logger.info('Response updated successfully', {
@@ -405,7 +658,7 @@ const updateResponse = async (req, res) => {
 
     res.json(
       createSuccessResponse(
-        updatedResponse,
+        toPublicResponse(updatedResponse),
         'Response updated successfully',
         correlationId
       )
@@ -461,6 +714,11 @@ const deleteResponse = async (req, res) => {
 
     // Delete the response
     mockDatabase.responses.delete(id);
+    // Test responses never hold the one response of their submitter
+    if (!response.isTest) {
+      mockDatabase.submitters.delete(`${response.formId}:${response.respondentId}`);
+    }
+    mockDatabase.revisions.delete(id);
 
     const duration = Date.now() - startTime;
 
@@ -520,7 +778,11 @@ const getFormResponses = async (req, res) => {
 const exportResponses = async (req, res) => {
   const correlationId = req.headers['x-correlation-id'] || req.correlationId;
   const { formId } = req.params;
-  const { format = 'csv', includeMetadata = false } = req.query;
+  const { format = 'csv', includeMetadata = false, includeRevisions = false, includePresentedOrder = false, includeTest = false } = req.query;
+  // Query flags arrive as strings
+  const withRevisions = includeRevisions === true || includeRevisions === 'true';
+  const withPresentedOrder = includePresentedOrder === true || includePresentedOrder === 'true';
+  const withTest = includeTest === true || includeTest === 'true';
   const startTime = Date.now();
   
   try {
@@ -528,12 +790,20 @@ const exportResponses = async (req, res) => {
       correlationId,
       formId,
       format,
-      includeMetadata
+      includeMetadata,
+      includeRevisions: withRevisions,
+      includePresentedOrder: withPresentedOrder,
+      includeTest: withTest
     });
 
-    // Get form responses
+    // Get form responses, with the earlier revisions of edited ones and test
+    // responses if asked for
     const responses = Array.from(mockDatabase.responses.values())
-      .filter(response => response.formId === formId);
+      .filter(response => response.formId === formId && (withTest || !response.isTest))
+      .map(response => ({
+        ...toPublicResponse(response),
+        ...(withRevisions && { revisions: mockDatabase.revisions.get(response.id) || [] })
+      }));
 
     if (responses.length === 0) {
       throw new NotFoundError('No responses found for this form');
@@ -550,13 +820,13 @@ const exportResponses = async (req, res) => {
       case 'csv':
         contentType = 'text/csv';
         fileExtension = 'csv';
-        exportData = convertToCSV(responses, includeMetadata);
+        exportData = convertToCSV(responses, includeMetadata, withRevisions, withPresentedOrder);
         break;
       
       case 'excel':
         contentType = 'application/vnd.openxmlformats-officedocument.spreadsheetml.sheet';
         fileExtension = 'xlsx';
-        exportData = convertToExcel(responses, includeMetadata);
+        exportData = convertToExcel(responses, includeMetadata, withRevisions, withPresentedOrder);
         break;
       
       case 'json':
@@ -576,7 +846,9 @@ const exportResponses = async (req, res) => {
     logger.logBusiness('RESPONSES_EXPORTED', 'form', formId, {
       format,
       responseCount: responses.length,
-      includeMetadata
+      includeMetadata,
+      includeRevisions: withRevisions,
+      includePresentedOrder: withPresentedOrder
     }, { correlationId });
 
     logger.info('Responses exported successfully', {
@@ -606,9 +878,12 @@ const exportResponses = async (req, res) => {
 };
 
 /**
- * Helper function to convert responses to CSV format
+ * Helper function to convert responses to CSV format. With includeRevisions,
+ * each response is followed by one row per earlier revision. With
+ * includePresentedOrder, each question some response has a presented order for
+ * is followed by the option keys in the order they were shown.
  */
-function convertToCSV(responses, includeMetadata) {
+function convertToCSV(responses, includeMetadata, includeRevisions = false, includePresentedOrder = false) {
   if (responses.length === 0) return '';
 
   // Get all unique question IDs
@@ -617,8 +892,25 @@ function convertToCSV(responses, includeMetadata) {
     response.responses.forEach(resp => {
       questionIds.add(resp.questionId);
     });
+    (response.revisions || []).forEach(revision => {
+      revision.answers.forEach(resp => {
+        questionIds.add(resp.questionId);
+      });
+    });
   });
 
+  // Questions with randomized options, which answers carry the presented order of
+  const presentedQuestionIds = new Set();
+  if (includePresentedOrder) {
+    responses.forEach(response => {
+      response.responses.forEach(resp => {
+        if (Array.isArray(resp.presentedOrder)) {
+          presentedQuestionIds.add(resp.questionId);
+        }
+      });
+    });
+  }
+
   // Create CSV header
   const headers = [
     'Response ID',
@@ -628,22 +920,42 @@ function convertToCSV(responses, includeMetadata) {
     'Status',
     'Submitted At',
     'Updated At',
-    ...Array.from(questionIds).map(id => `Question_${id}`)
+    ...Array.from(questionIds).flatMap(id => presentedQuestionIds.has(id)
+      ? [`Question_${id}`, `Question_${id} (presented order)`]
+      : [`Question_${id}`])
   ];
 
   if (includeMetadata) {
     headers.push('IP Address', 'User Agent', 'Time Spent');
   }
 
+  if (includeRevisions) {
+    headers.push('Revision', 'Superseded At');
+  }
+
   // Create CSV rows
   const rows = [headers];
+
+  const versions = response => includeRevisions
+    ? [
+      { ...response, supersededAt: '' },
+      ...(response.revisions || []).map(revision => ({
+        ...response,
+        responses: revision.answers,
+        revision: revision.revision,
+        supersededAt: revision.editedAt
+      }))
+    ]
+    : [response];
   
-  responses.forEach(response => {
+  responses.flatMap(versions).forEach(response => {
+    // Anonymous responses never export the respondent identity
+    const anonymized = responseModes.isAnonymized(response);
     const row = [
       response.id,
       response.formId,
-      response.respondentEmail || '',
-      response.respondentName || '',
+      anonymized ? '' : response.respondentEmail || '',
+      anonymized ? '' : response.respondentName || '',
       response.status,
       response.submittedAt,
       response.updatedAt
@@ -653,6 +965,9 @@ function convertToCSV(responses, includeMetadata) {
     questionIds.forEach(questionId => {
       const questionResponse = response.responses.find(resp => resp.questionId === questionId);
       row.push(questionResponse ? questionResponse.textValue || JSON.stringify(questionResponse.value) : '');
+      if (presentedQuestionIds.has(questionId)) {
+        row.push((questionResponse?.presentedOrder || []).join('; '));
+      }
     });
 
     if (includeMetadata) {
@@ -663,6 +978,10 @@ function convertToCSV(responses, includeMetadata) {
       );
     }
 
+    if (includeRevisions) {
+      row.push(response.revision || 1, response.supersededAt || '');
+    }
+
     rows.push(row);
   });
 
@@ -675,18 +994,21 @@ function convertToCSV(responses, includeMetadata) {
 /**
  * Helper function to convert responses to Excel format (simplified)
  */
-function convertToExcel(responses, includeMetadata) {
+function convertToExcel(responses, includeMetadata, includeRevisions = false, includePresentedOrder = false) {
   // For simplicity, return CSV format with Excel content type
   // In a real implementation, you would use a library like 'exceljs'
-  return convertToCSV(responses, includeMetadata);
+  return convertToCSV(responses, includeMetadata, includeRevisions, includePresentedOrder);
 }
 
 module.exports = {
   createResponse,
   getResponses,
   getResponse,
+  getResponseReceipt,
   updateResponse,
   deleteResponse,
   getFormResponses,
-  exportResponses
+  exportResponses,
+  // The store, for the retention worker
+  store: mockDatabase
 };
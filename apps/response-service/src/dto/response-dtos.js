@@ -99,6 +99,14 @@ const createResponseRequestDTO = {
         .max(5000)
         .description('Text representation of the answer for searching'),
       
+      presentedOrder: Joi.array()
+        .items(Joi.string().max(200))
+        .unique()
+        .max(200)
+        .optional()
+        .description('Option keys in the order they were shown, for questions with randomized options')
+        .example(['team', 'pro', 'free', 'other']),
+      
       files: Joi.array()
         .items(Joi.object({
           fileName: Joi.string().required(),
@@ -123,13 +131,43 @@ const createResponseRequestDTO = {
     timeSpent: Joi.number().positive().optional().description('Time spent in seconds')
   }).optional().description('Additional metadata about the response'),
   
+  timings: Joi.object()
+    .optional()
+    .description('Timing beacons of the client: { focusMs, revisits } by question ID, the time each question held focus and how often it was returned to. Used for per-question timing analytics only; malformed payloads are dropped without failing the submission')
+    .example({ 'q123e4567-e89b-12d3-a456-426614174000': { focusMs: 8400, revisits: 1 } }),
+
   isDraft: Joi.boolean()
     .default(false)
     .description('Whether this is a draft submission'),
   
   isPartial: Joi.boolean()
     .default(false)
-    .description('Whether this is a partial submission (not all required questions answered)')
+    .description('Whether this is a partial submission (not all required questions answered)'),
+
+  captchaToken: Joi.string()
+    .max(4096)
+    .optional()
+    .description('Token of the CAPTCHA widget, required by forms with captcha spam protection'),
+
+  honeypot: Joi.string()
+    .allow('')
+    .max(1000)
+    .optional()
+    .description('Value of the hidden honeypot field, which must be empty'),
+
+  challengeToken: Joi.string()
+    .max(200)
+    .optional()
+    .description('spam_protection.token of the form definition, required by forms with honeypot spam protection'),
+
+  previewToken: Joi.string()
+    .max(2048)
+    .optional()
+    .description('Token of a form preview; previews are read-only and submissions carrying one are rejected'),
+
+  test: Joi.boolean()
+    .optional()
+    .description('Submit a test response, as the X-Test-Submission header does; requires the owner or a collaborator of the form')
 };
 
 /**
@@ -258,6 +296,11 @@ const responseResponseDTO = {
           type: 'string',
           description: 'Text representation of the answer'
         },
+        presentedOrder: {
+          type: 'array',
+          items: { type: 'string' },
+          description: 'Option keys in the order they were shown, for questions with randomized options'
+        },
         files: {
           type: 'array',
           items: {
@@ -290,6 +333,11 @@ const responseResponseDTO = {
     type: 'boolean',
     description: 'Whether this is a partial submission'
   },
+
+  isTest: {
+    type: 'boolean',
+    description: 'Whether this is a test submission of the owner or a collaborator of the form'
+  },
   
   metadata: {
     type: 'object',
@@ -22,9 +22,14 @@ const authMiddleware = require('./middleware/auth');
 const validationMiddleware = require('./middleware/validation');
 const securityMiddleware = require('./middleware/security');
 const errorHandler = require('./middleware/errorHandler');
+const embedOrigins = require('./utils/embedOrigins');
+const metrics = require('./utils/metrics');
+const { createRetentionWorker } = require('./services/RetentionWorker');
+const responseController = require('./controllers/responseController');
 
 // Import routes
 const v1Routes = require('./routes/v1');
+const internalRoutes = require('./routes/internal');
 
 // Import swagger configuration
 const { specs, swaggerUi, swaggerUiOptions } = require('./config/swagger');
@@ -49,13 +54,13 @@ class ResponseServiceApplication {
       hsts: config.get('security.enableHsts')
     }));
 
-    // CORS configuration
-    this.app.use(cors({
+    // CORS configuration; embedded forms submit from the sites they are embedded on
+    this.app.use(cors(embedOrigins.withEmbedCors({
       origin: config.get('security.corsOrigins'),
       credentials: true,
       methods: ['GET', 'POST', 'PUT', 'DELETE', 'PATCH', 'OPTIONS'],
-      allowedHeaders: ['Content-Type', 'Authorization', 'X-API-Key', 'X-Correlation-ID']
-    }));
+      allowedHeaders: ['Content-Type', 'Authorization', 'X-API-Key', 'X-Correlation-ID', 'X-Respondent-Token', 'X-Edit-Token']
+    })));
 
     // Compression
     if (config.get('api.enableCompression')) {
@@ -141,9 +146,19 @@ class ResponseServiceApplication {
       });
     }
 
+    // Prometheus metrics
+    if (config.get('monitoring.enableMetrics')) {
+      this.app.get('/metrics', (req, res) => {
+        res.type('text/plain; version=0.0.4').send(metrics.render());
+      });
+    }
+
     // API Routes
     this.app.use('/api/v1', v1Routes);
 
+    // Internal endpoints for other services, authenticated with the API key
+    this.app.use('/internal', internalRoutes);
+
     // Root endpoint
     this.app.get('/', (req, res) => {
       res.json({
@@ -282,6 +297,10 @@ class ResponseServiceApplication {
         process.exit(1);
       });
 
+      // Purge responses older than the retention of their form
+      this.retentionWorker = createRetentionWorker(responseController.store);
+      this.retentionWorker.start();
+
       // Set server timeouts
       this.server.keepAliveTimeout = config.get('performance.keepAliveTimeout') || 5000;
       this.server.headersTimeout = config.get('performance.headersTimeout') || 60000;
@@ -341,6 +360,11 @@ class ResponseServiceApplication {
    * Cleanup resources
    */
   async cleanup() {
+    // Let a retention run finish its batch
+    if (this.retentionWorker) {
+      await this.retentionWorker.stop();
+    }
+
     // Cleanup event system
     if (eventSystem) {
       eventSystem.cleanupOldEvents();
@@ -0,0 +1,181 @@
+/**
+ * CAPTCHA Verification
+ * Verifies the CAPTCHA tokens of submissions with the provider configured
+ * for the service. reCAPTCHA, hCaptcha and Turnstile speak the same
+ * siteverify protocol, so their verifiers share one HTTP client. Errors on
+ * the provider's side (outages, timeouts, a rejected secret) are remembered
+ * for a cooldown, so that they don't cost every submission a timeout.
+ */
+
+const axios = require('axios');
+const logger = require('../utils/logger');
+const { createError } = require('../middleware/errorHandler');
+
+// Error codes the providers answer for a misconfigured service rather than a bad token
+const PROVIDER_ERROR_CODES = new Set([
+  'missing-input-secret',
+  'invalid-input-secret',
+  'sitekey-secret-mismatch',
+  'invalid-sitekey',
+  'internal-error'
+]);
+
+/**
+ * Error thrown while the provider can't verify tokens
+ * @param {string} provider - Provider name
+ * @returns {Error} 503 CAPTCHA_UNAVAILABLE
+ */
+const unavailableError = provider =>
+  createError(`CAPTCHA verification with ${provider} is unavailable, please try again later`, 503, 'CAPTCHA_UNAVAILABLE');
+
+/**
+ * HTTP client of the siteverify endpoints, shared by the verifiers
+ */
+class VerificationClient {
+  /**
+   * @param {Object} options
+   * @param {number} options.timeout - Request timeout in milliseconds
+   * @param {number} options.errorCooldown - How long a provider error is remembered, in milliseconds
+   */
+  constructor({ timeout, errorCooldown }) {
+    this.errorCooldown = errorCooldown;
+    // Provider errors by endpoint, with the time they are remembered until
+    this.providerErrors = new Map();
+    this.http = axios.create({
+      timeout,
+      headers: {
+        'Content-Type': 'application/x-www-form-urlencoded',
+        'User-Agent': 'Response-Service/1.0'
+      }
+    });
+  }
+
+  /**
+   * Verify a token with a siteverify endpoint
+   * @param {string} provider - Provider name, for errors and logs
+   * @param {string} endpoint - siteverify URL
+   * @param {Object} params - Form parameters: secret, response and remoteip
+   * @returns {Promise<Object>} Provider answer: success, error-codes and provider extras
+   * @throws {Error} 503 while the provider errs
+   */
+  async siteverify(provider, endpoint, params) {
+    const remembered = this.providerErrors.get(endpoint);
+    if (remembered && remembered.until > Date.now()) {
+      throw unavailableError(provider);
+    }
+
+    let answer;
+    try {
+      const response = await this.http.post(endpoint, new URLSearchParams(params).toString());
+      answer = response.data || {};
+    } catch (error) {
+      this.rememberError(provider, endpoint, error.message);
+      throw unavailableError(provider);
+    }
+
+    const providerErrors = (answer['error-codes'] || []).filter(code => PROVIDER_ERROR_CODES.has(code));
+    if (providerErrors.length > 0) {
+      this.rememberError(provider, endpoint, providerErrors.join(', '));
+      throw unavailableError(provider);
+    }
+
+    this.providerErrors.delete(endpoint);
+    return answer;
+  }
+
+  rememberError(provider, endpoint, reason) {
+    this.providerErrors.set(endpoint, { reason, until: Date.now() + this.errorCooldown });
+    logger.error('CAPTCHA provider error', { provider, reason, cooldownMs: this.errorCooldown });
+  }
+}
+
+/**
+ * Verifier of the siteverify protocol. Verifiers implement
+ * verify(token, remoteIp), resolving whether the token was issued to a person.
+ */
+class SiteverifyVerifier {
+  constructor(provider, endpoint, secret, client) {
+    this.provider = provider;
+    this.endpoint = endpoint;
+    this.secret = secret;
+    this.client = client;
+  }
+
+  /**
+   * @param {string} token - Token the widget issued
+   * @param {string} remoteIp - IP address of the respondent
+   * @returns {Promise<boolean>} Whether the token passed
+   * @throws {Error} 503 while the provider errs
+   */
+  async verify(token, remoteIp) {
+    const answer = await this.client.siteverify(this.provider, this.endpoint, {
+      secret: this.secret,
+      response: token,
+      ...(remoteIp && { remoteip: remoteIp })
+    });
+    return this.passed(answer);
+  }
+
+  passed(answer) {
+    return answer.success === true;
+  }
+}
+
+class RecaptchaVerifier extends SiteverifyVerifier {
+  constructor(secret, client, minScore) {
+    super('recaptcha', 'https://www.google.com/recaptcha/api/siteverify', secret, client);
+    this.minScore = minScore;
+  }
+
+  // reCAPTCHA v3 scores the respondent instead of challenging them
+  passed(answer) {
+    return super.passed(answer) && (answer.score === undefined || answer.score >= this.minScore);
+  }
+}
+
+class HCaptchaVerifier extends SiteverifyVerifier {
+  constructor(secret, client) {
+    super('hcaptcha', 'https://api.hcaptcha.com/siteverify', secret, client);
+  }
+}
+
+class TurnstileVerifier extends SiteverifyVerifier {
+  constructor(secret, client) {
+    super('turnstile', 'https://challenges.cloudflare.com/turnstile/v0/siteverify', secret, client);
+  }
+}
+
+/**
+ * Create the verifier of a provider
+ * @param {Object} options
+ * @param {string} options.provider - recaptcha, hcaptcha or turnstile
+ * @param {string} options.secret - Secret key of the provider
+ * @param {VerificationClient} options.client - Shared HTTP client
+ * @param {number} options.recaptchaMinScore - Least reCAPTCHA v3 score that passes
+ * @returns {SiteverifyVerifier|null} Verifier, null for unknown providers or without a secret
+ */
+function createCaptchaVerifier({ provider, secret, client, recaptchaMinScore = 0.5 }) {
+  if (!secret) {
+    return null;
+  }
+  switch (provider) {
+    case 'recaptcha':
+      return new RecaptchaVerifier(secret, client, recaptchaMinScore);
+    case 'hcaptcha':
+      return new HCaptchaVerifier(secret, client);
+    case 'turnstile':
+      return new TurnstileVerifier(secret, client);
+    default:
+      return null;
+  }
+}
+
+module.exports = {
+  VerificationClient,
+  SiteverifyVerifier,
+  RecaptchaVerifier,
+  HCaptchaVerifier,
+  TurnstileVerifier,
+  createCaptchaVerifier,
+  unavailableError
+};
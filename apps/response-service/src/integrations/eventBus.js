@@ -0,0 +1,352 @@
+/**
+ * Event Bus Integration Layer
+ * Publishes response lifecycle events to the event bus service, which projects
+ * them into analytics-friendly tables
+ */
+
+const crypto = require('crypto');
+const axios = require('axios');
+const logger = require('../utils/logger');
+
+// Event type published for every accepted submission
+const RESPONSE_CREATED_EVENT = 'form.response.created';
+// Event type published for every edit of a submitted response
+const RESPONSE_UPDATED_EVENT = 'form.response.updated';
+// Event type published when responses of a form are purged for retention
+const RESPONSES_PURGED_EVENT = 'form.response.purged';
+// Audit event recording a purge, once per form and run
+const RESPONSES_PURGED_AUDIT_EVENT = 'audit.form.responses_purged';
+
+class EventBusIntegration {
+  constructor() {
+    this.baseURL = process.env.EVENT_BUS_URL || 'http://localhost:8080';
+    this.timeout = parseInt(process.env.EVENT_BUS_TIMEOUT) || 5000;
+    this.retryAttempts = parseInt(process.env.EVENT_BUS_RETRY_ATTEMPTS) || 5;
+    this.retryDelay = parseInt(process.env.EVENT_BUS_RETRY_DELAY) || 500;
+    this.enabled = process.env.EVENT_BUS_ENABLED !== 'false';
+
+    this.client = axios.create({
+      baseURL: this.baseURL,
+      timeout: this.timeout,
+      headers: {
+        'Content-Type': 'application/json',
+        'Accept': 'application/json',
+        'User-Agent': 'Response-Service/1.0'
+      }
+    });
+  }
+
+  /**
+   * Publish a form.response.created event for an accepted submission. The
+   * event ID is derived from the response ID, so retried publishes of the
+   * same submission are recognised as duplicates downstream.
+   * @param {Object} response - The saved response
+   * @param {Object} formSchema - Form schema the response was validated against
+   * @param {Object} metadata - Submission metadata; timings are the timing
+   * beacons of the client, if any
+   * @returns {Promise<string|null>} Published event ID, or null when disabled
+   */
+  async publishResponseCreated(response, formSchema = null, metadata = {}) {
+    return this.publishResponseEvent(
+      RESPONSE_CREATED_EVENT,
+      `response-created-${response.id}`,
+      response,
+      formSchema?.version || 1,
+      this.questionKeys(formSchema),
+      metadata
+    );
+  }
+
+  /**
+   * Publish a form.response.updated event for an edited response. The event
+   * carries the full answers of the new revision, which replace the answers
+   * of earlier revisions in the projection.
+   * @param {Object} response - The edited response
+   * @param {Object} formSchema - Form schema the edit was validated against
+   * @param {Object} metadata - Edit metadata
+   * @returns {Promise<string|null>} Published event ID, or null when disabled
+   */
+  async publishResponseUpdated(response, formSchema = null, metadata = {}) {
+    return this.publishResponseEvent(
+      RESPONSE_UPDATED_EVENT,
+      `response-updated-${response.id}-v${response.revision}`,
+      response,
+      response.formVersion || 1,
+      this.questionKeys(formSchema),
+      metadata
+    );
+  }
+
+  /**
+   * Stable keys of the questions of a form schema, by question ID. Analytics
+   * join answers on them, so that the answers to a question deleted and
+   * recreated under the same key are counted together.
+   * @param {Object} formSchema - Form schema
+   * @returns {Object|undefined} Keys by question ID, or undefined when the
+   *   schema has none
+   */
+  questionKeys(formSchema) {
+    const keys = {};
+    for (const question of formSchema?.questions || []) {
+      if (question.id && question.key) {
+        keys[question.id] = question.key;
+      }
+    }
+    return Object.keys(keys).length > 0 ? keys : undefined;
+  }
+
+  /**
+   * Publish a response lifecycle event keyed on the form ID
+   * @param {string} eventType - Event type
+   * @param {string} eventId - Deterministic event ID
+   * @param {Object} response - The response
+   * @param {number} formVersion - Form version the response was submitted under
+   * @param {Object} questionKeys - Question keys by question ID, if any
+   * @param {Object} metadata - Request metadata
+   * @returns {Promise<string|null>} Published event ID, or null when disabled
+   */
+  async publishResponseEvent(eventType, eventId, response, formVersion, questionKeys, metadata = {}) {
+    if (!this.enabled) {
+      return null;
+    }
+
+    const answers = this.normalizeAnswers(response.responses);
+    const event = {
+      id: eventId,
+      event_type: eventType,
+      source: 'response-service',
+      subject: response.id,
+      key: response.formId,
+      headers: metadata.correlationId ? { 'correlation-id': metadata.correlationId } : {},
+      data: {
+        response_id: response.id,
+        form_id: response.formId,
+        form_version: formVersion,
+        revision: response.revision || 1,
+        answers_hash: this.hashAnswers(answers),
+        answers,
+        presented_order: Object.keys(response.presentedOrder || {}).length > 0 ? response.presentedOrder : undefined,
+        question_keys: questionKeys,
+        // Only submissions carry timings; edits keep those of the submission
+        timings: eventType === RESPONSE_CREATED_EVENT ? this.normalizeTimings(metadata.timings) : undefined,
+        respondent: {
+          id: response.isAnonymous ? undefined : response.submitterId || undefined,
+          anonymous: !!response.isAnonymous,
+          session_id: response.sessionId || undefined,
+          source: metadata.source || 'web'
+        },
+        submitted_at: this.toISOString(response.submittedAt),
+        // Test responses are projected but left out of counts and notifications
+        is_test: response.isTest === true || undefined
+      }
+    };
+
+    await this.retryRequest(() => this.client.post('/events', event, {
+      headers: metadata.correlationId ? { 'X-Correlation-ID': metadata.correlationId } : {}
+    }));
+
+    logger.info('Response event published', {
+      eventId: event.id,
+      eventType,
+      responseId: response.id,
+      formId: response.formId,
+      correlationId: metadata.correlationId
+    });
+
+    return event.id;
+  }
+
+  /**
+   * Publish a form.response.purged event for the responses of a form purged
+   * in a retention run, so projections drop or anonymize their rows. The
+   * event carries counts, never the content of the responses.
+   * @param {Object} purge - formId, runId, mode, count and purgedBefore
+   * @returns {Promise<string|null>} Published event ID, or null when disabled
+   */
+  async publishResponsesPurged({ formId, runId, mode, count, purgedBefore }) {
+    return this.publishEvent({
+      id: `responses-purged-${formId}-${runId}`,
+      event_type: RESPONSES_PURGED_EVENT,
+      source: 'response-service',
+      subject: formId,
+      key: formId,
+      headers: {},
+      data: {
+        form_id: formId,
+        mode,
+        count,
+        purged_before: purgedBefore
+      }
+    });
+  }
+
+  /**
+   * Publish the audit entry of the purge of the responses of a form
+   * @param {Object} purge - formId, runId, mode, count, retentionDays and
+   *   purgedBefore
+   * @returns {Promise<string|null>} Published event ID, or null when disabled
+   */
+  async publishPurgeAudit({ formId, runId, mode, count, retentionDays, purgedBefore }) {
+    return this.publishEvent({
+      id: `audit-responses-purged-${formId}-${runId}`,
+      event_type: RESPONSES_PURGED_AUDIT_EVENT,
+      source: 'response-service',
+      subject: formId,
+      key: formId,
+      headers: {},
+      data: {
+        actor: 'system:retention',
+        resource_type: 'form',
+        resource_id: formId,
+        after: {
+          mode,
+          responses_purged: count,
+          retention_days: retentionDays,
+          purged_before: purgedBefore
+        },
+        occurred_at: new Date().toISOString()
+      }
+    });
+  }
+
+  /**
+   * Publish an event as is
+   * @param {Object} event - Event
+   * @returns {Promise<string|null>} Published event ID, or null when disabled
+   */
+  async publishEvent(event) {
+    if (!this.enabled) {
+      return null;
+    }
+    await this.retryRequest(() => this.client.post('/events', event));
+    logger.info('Event published', { eventId: event.id, eventType: event.event_type });
+    return event.id;
+  }
+
+  /**
+   * Reduce stored answers to their values, keyed by question ID
+   * @param {Object} responses - Answers as stored on the response
+   * @returns {Object} Answer values
+   */
+  normalizeAnswers(responses = {}) {
+    const answers = {};
+    for (const [questionId, answer] of Object.entries(responses)) {
+      answers[questionId] = answer && typeof answer === 'object' && !Array.isArray(answer) && 'value' in answer
+        ? answer.value
+        : answer;
+    }
+    return answers;
+  }
+
+  /**
+   * Rename the timing beacons of a question to the fields of the event,
+   * { focus_ms, revisits }. Values are passed on as sent; the projection
+   * validates them.
+   * @param {Object} timings - Timing beacons by question ID
+   * @returns {Object|undefined} Event timings, or undefined when none
+   */
+  normalizeTimings(timings) {
+    if (!timings || typeof timings !== 'object' || Object.keys(timings).length === 0) {
+      return undefined;
+    }
+    const normalized = {};
+    for (const [questionId, timing] of Object.entries(timings)) {
+      normalized[questionId] = timing && typeof timing === 'object' && !Array.isArray(timing)
+        ? { focus_ms: timing.focusMs, revisits: timing.revisits }
+        : timing;
+    }
+    return normalized;
+  }
+
+  /**
+   * Hash answers independently of key order
+   * @param {Object} answers - Answer values
+   * @returns {string} sha256 hash prefixed with the algorithm
+   */
+  hashAnswers(answers) {
+    const digest = crypto.createHash('sha256').update(this.stableStringify(answers)).digest('hex');
+    return `sha256:${digest}`;
+  }
+
+  /**
+   * JSON.stringify with object keys sorted at every level
+   * @param {*} value - Value to serialize
+   * @returns {string} Canonical JSON
+   */
+  stableStringify(value) {
+    if (Array.isArray(value)) {
+      return `[${value.map(item => this.stableStringify(item)).join(',')}]`;
+    }
+    if (value && typeof value === 'object') {
+      const keys = Object.keys(value).filter(key => value[key] !== undefined).sort();
+      return `{${keys.map(key => `${JSON.stringify(key)}:${this.stableStringify(value[key])}`).join(',')}}`;
+    }
+    return JSON.stringify(value === undefined ? null : value);
+  }
+
+  /**
+   * Convert a Firestore timestamp, Date or server timestamp sentinel to ISO 8601
+   * @param {*} timestamp - Timestamp value
+   * @returns {string} ISO 8601 timestamp
+   */
+  toISOString(timestamp) {
+    if (timestamp && typeof timestamp.toDate === 'function') {
+      return timestamp.toDate().toISOString();
+    }
+    if (timestamp instanceof Date) {
+      return timestamp.toISOString();
+    }
+    return new Date().toISOString();
+  }
+
+  /**
+   * Retry mechanism for HTTP requests
+   * @param {Function} requestFunction - The request function to retry
+   * @param {number} attempts - Number of retry attempts
+   * @returns {Promise} Request result
+   */
+  async retryRequest(requestFunction, attempts = this.retryAttempts) {
+    let lastError;
+
+    for (let i = 0; i < attempts; i++) {
+      try {
+        return await requestFunction();
+      } catch (error) {
+        lastError = error;
+
+        // Don't retry on client errors (4xx)
+        if (error.response?.status && error.response.status < 500) {
+          throw error;
+        }
+
+        if (i < attempts - 1) {
+          const delay = this.retryDelay * Math.pow(2, i);
+          logger.warn(`Event publish failed, retrying in ${delay}ms`, {
+            attempt: i + 1,
+            maxAttempts: attempts,
+            error: error.message
+          });
+          await this.sleep(delay);
+        }
+      }
+    }
+
+    throw lastError;
+  }
+
+  /**
+   * Sleep utility for retry delays
+   * @param {number} ms - Milliseconds to sleep
+   * @returns {Promise}
+   */
+  sleep(ms) {
+    return new Promise(resolve => setTimeout(resolve, ms));
+  }
+}
+
+// Export singleton instance
+module.exports = new EventBusIntegration();
+module.exports.RESPONSE_CREATED_EVENT = RESPONSE_CREATED_EVENT;
+module.exports.RESPONSE_UPDATED_EVENT = RESPONSE_UPDATED_EVENT;
+module.exports.RESPONSES_PURGED_EVENT = RESPONSES_PURGED_EVENT;
+module.exports.RESPONSES_PURGED_AUDIT_EVENT = RESPONSES_PURGED_AUDIT_EVENT;
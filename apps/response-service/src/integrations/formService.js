@@ -5,7 +5,7 @@
 
 const axios = require('axios');
 const logger = require('../utils/logger');
-const { ValidationError, ServiceUnavailableError } = require('../middleware/errorHandler');
+const { ValidationError, ServiceUnavailableError, createError } = require('../middleware/errorHandler');
 
 class FormServiceIntegration {
   constructor() {
@@ -34,6 +34,11 @@ class FormServiceIntegration {
           config.headers['X-API-Key'] = process.env.FORM_SERVICE_API_KEY;
         }
 
+        // Internal endpoints admit services with the shared service token
+        if (process.env.INTERNAL_SERVICE_TOKEN && config.url.startsWith(`${this.baseURL}/internal/`)) {
+          config.headers['Authorization'] = `Bearer ${process.env.INTERNAL_SERVICE_TOKEN}`;
+        }
+
         // Add correlation ID for tracing
         if (config.correlationId) {
           config.headers['X-Correlation-ID'] = config.correlationId;
@@ -144,14 +149,16 @@ class FormServiceIntegration {
    * Get form schema for validation
    * @param {string} formId - The form ID
    * @param {string} correlationId - Request correlation ID
+   * @param {number} [version] - Form version to fetch; defaults to the current one
    * @returns {Promise<Object>} Form schema
    */
-  async getFormSchema(formId, correlationId) {
+  async getFormSchema(formId, correlationId, version) {
     try {
-      logger.info('Fetching form schema', { formId, correlationId });
+      logger.info('Fetching form schema', { formId, version, correlationId });
 
       const response = await this.retryRequest(async () => {
         return await this.client.get(`/forms/${formId}/schema`, {
+          params: version ? { version } : undefined,
           correlationId,
           metadata: { startTime: Date.now() }
         });
@@ -279,6 +286,268 @@ class FormServiceIntegration {
     }
   }
 
+  /**
+   * Verify the file tokens submitted for a file question and attach the uploads to a response
+   * @param {string} formId - The form ID
+   * @param {string} questionId - The file question ID
+   * @param {Array<string>} tokens - File tokens returned by the upload-url endpoint
+   * @param {string} responseId - The response the files belong to
+   * @param {string} correlationId - Request correlation ID
+   * @returns {Promise<Object>} Verification result
+   */
+  async verifyFileTokens(formId, questionId, tokens, responseId, correlationId) {
+    try {
+      // Internal endpoint, served outside the versioned API
+      const response = await this.retryRequest(async () => {
+        return await this.client.post(`${this.baseURL}/internal/forms/${formId}/questions/${questionId}/files/verify`, {
+          tokens,
+          response_id: responseId
+        }, {
+          correlationId,
+          metadata: { startTime: Date.now() }
+        });
+      });
+
+      return {
+        isValid: true,
+        files: response.data.files || []
+      };
+
+    } catch (error) {
+      if (error.response?.status === 422 || error.response?.status === 400) {
+        return {
+          isValid: false,
+          error: error.response.data?.error || 'Invalid file upload'
+        };
+      }
+
+      logger.error('Failed to verify file tokens', {
+        formId,
+        questionId,
+        error: error.message,
+        status: error.response?.status,
+        correlationId
+      });
+
+      throw new ServiceUnavailableError('Form service is currently unavailable');
+    }
+  }
+
+  /**
+   * Consume the autosaved draft of a respondent once their response is submitted.
+   * The form service removes it atomically, so a draft is consumed at most once.
+   * @param {string} formId - The form ID
+   * @param {Object} owner - { userId, respondentToken } identifying the draft
+   * @param {string} correlationId - Request correlation ID
+   * @returns {Promise<boolean>} Whether a draft was consumed
+   */
+  async consumeDraft(formId, owner, correlationId) {
+    if (!owner.userId && !owner.respondentToken) {
+      return false;
+    }
+
+    try {
+      // Internal endpoint, served outside the versioned API
+      await this.retryRequest(async () => {
+        return await this.client.post(`${this.baseURL}/internal/forms/${formId}/responses/draft/consume`, {
+          user_id: owner.userId || '',
+          respondent_token: owner.respondentToken || ''
+        }, {
+          correlationId,
+          metadata: { startTime: Date.now() }
+        });
+      });
+
+      return true;
+
+    } catch (error) {
+      if (error.response?.status === 404) {
+        return false;
+      }
+
+      logger.warn('Failed to consume response draft', {
+        formId,
+        error: error.message,
+        status: error.response?.status,
+        correlationId
+      });
+
+      // Unconsumed drafts expire on their own
+      return false;
+    }
+  }
+
+  /**
+   * Admit a response against the monthly response quota of the organization
+   * of the form. Admitting the same response again does not count it twice.
+   * Fails open: a response is only refused when the quota is known to be
+   * reached.
+   * @param {string} formId - Form ID
+   * @param {string} responseId - ID of the response about to be stored
+   * @param {string} correlationId - Request correlation ID
+   * @returns {Promise<void>}
+   */
+  async admitResponse(formId, responseId, correlationId) {
+    try {
+      // Internal endpoint, served outside the versioned API
+      await this.retryRequest(async () => {
+        return await this.client.post(`${this.baseURL}/internal/forms/${formId}/usage/responses`, {
+          response_id: responseId
+        }, {
+          correlationId,
+          metadata: { startTime: Date.now() }
+        });
+      });
+    } catch (error) {
+      if (error.response?.status === 402) {
+        throw createError(
+          error.response.data?.error || 'The response quota of this form has been reached',
+          402,
+          'UPGRADE_REQUIRED'
+        );
+      }
+
+      // Reconciliation counts the responses the meter missed
+      logger.warn('Failed to admit response against the usage quota', {
+        formId,
+        responseId,
+        error: error.message,
+        status: error.response?.status,
+        correlationId
+      });
+    }
+  }
+
+  /**
+   * Check answers against the cross-field rules of a form, such as check-out
+   * after check-in or percentages adding up to 100
+   * @param {string} formId - Form ID
+   * @param {Object} answers - Answers keyed by question ID
+   * @param {string} locale - Locale of the respondent, for the rule messages
+   * @param {string} correlationId - Request correlation ID
+   * @returns {Promise<Array>} Broken rules as { rule_id, question_ids, message }
+   */
+  async checkResponseRules(formId, answers, locale, correlationId) {
+    try {
+      // Internal endpoint, served outside the versioned API
+      const response = await this.retryRequest(async () => {
+        return await this.client.post(`${this.baseURL}/internal/forms/${formId}/responses/validate`, {
+          answers,
+          locale
+        }, {
+          correlationId,
+          metadata: { startTime: Date.now() }
+        });
+      });
+
+      return response.data.errors || [];
+
+    } catch (error) {
+      logger.error('Failed to check response rules', {
+        formId,
+        error: error.message,
+        status: error.response?.status,
+        correlationId
+      });
+
+      throw new ServiceUnavailableError('Form service is currently unavailable');
+    }
+  }
+
+  /**
+   * Check a user may submit test responses to a form: the owner and the
+   * collaborators of a published form may. Fails closed.
+   * @param {string} formId - Form ID
+   * @param {string} userId - ID of the authenticated user
+   * @param {string} correlationId - Request correlation ID
+   * @returns {Promise<void>}
+   */
+  async authorizeTestSubmission(formId, userId, correlationId) {
+    try {
+      // Internal endpoint, served outside the versioned API
+      await this.retryRequest(async () => {
+        return await this.client.post(`${this.baseURL}/internal/forms/${formId}/responses/test/authorize`, {
+          user_id: userId
+        }, {
+          correlationId,
+          metadata: { startTime: Date.now() }
+        });
+      });
+    } catch (error) {
+      const status = error.response?.status;
+      if (status === 403 || status === 404) {
+        throw createError(
+          'Test submissions are only accepted from the owner and the collaborators of a published form',
+          403,
+          'TEST_MODE_FORBIDDEN'
+        );
+      }
+
+      logger.error('Failed to authorize test submission', {
+        formId,
+        error: error.message,
+        status,
+        correlationId
+      });
+
+      throw new ServiceUnavailableError('Form service is currently unavailable');
+    }
+  }
+
+  /**
+   * Render the PDF receipt of a response. Without userId the caller was
+   * checked to be the respondent; with it, the form service checks the user
+   * may view the form.
+   * @param {string} formId - Form ID
+   * @param {Object} receipt - { responseId, revision, submittedAt, answers, locale, userId }
+   * @param {string} correlationId - Request correlation ID
+   * @returns {Promise<Object>} { pdf, hash } with the PDF as a Buffer
+   */
+  async renderReceipt(formId, receipt, correlationId) {
+    try {
+      // Internal endpoint, served outside the versioned API
+      const response = await this.retryRequest(async () => {
+        return await this.client.post(`${this.baseURL}/internal/forms/${formId}/responses/receipt`, {
+          response_id: receipt.responseId,
+          revision: receipt.revision || 1,
+          submitted_at: receipt.submittedAt,
+          answers: receipt.answers,
+          locale: receipt.locale || undefined,
+          user_id: receipt.userId || undefined
+        }, {
+          responseType: 'arraybuffer',
+          headers: { Accept: 'application/pdf' },
+          correlationId,
+          metadata: { startTime: Date.now() }
+        });
+      });
+
+      return {
+        pdf: Buffer.from(response.data),
+        hash: response.headers['x-receipt-hash']
+      };
+
+    } catch (error) {
+      const status = error.response?.status;
+      if (status === 403) {
+        throw createError('You are not allowed to download the receipt of this response', 403, 'RECEIPT_FORBIDDEN');
+      }
+      if (status === 404) {
+        throw createError('Form not found', 404, 'FORM_NOT_FOUND');
+      }
+
+      logger.error('Failed to render response receipt', {
+        formId,
+        responseId: receipt.responseId,
+        error: error.message,
+        status,
+        correlationId
+      });
+
+      throw new ServiceUnavailableError('Form service is currently unavailable');
+    }
+  }
+
   /**
    * Get list of forms for analytics
    * @param {string} correlationId - Request correlation ID
@@ -0,0 +1,60 @@
+/**
+ * Redis Client
+ * Clients of the redis package for the features coordinating through the
+ * cache's Redis. Commands are not queued while the connection is down, so
+ * callers fail fast and fall back; the connection is retried in the
+ * background.
+ */
+
+const redis = require('redis');
+const config = require('../config/enhanced');
+const logger = require('../utils/logger');
+
+/**
+ * Create a client of the cache's Redis and start connecting it
+ * @param {string} name - Feature using the client, for logs
+ * @param {number} timeout - Connect timeout in milliseconds
+ * @returns {Object} redis client
+ */
+function createClient(name, timeout) {
+  const cache = config.get('cache');
+  const client = redis.createClient({
+    socket: {
+      host: cache.redisHost,
+      port: cache.redisPort,
+      connectTimeout: timeout
+    },
+    password: cache.redisPassword || undefined,
+    database: cache.redisDatabase,
+    disableOfflineQueue: true
+  });
+
+  client.on('error', (err) => {
+    logger.error(`Redis ${name} error:`, { error: err.message });
+  });
+  client.connect().catch((err) => {
+    logger.error(`Failed to connect Redis client for ${name}:`, { error: err.message });
+  });
+
+  return client;
+}
+
+/**
+ * Fail a command not answered within timeout, since a stalled connection
+ * would otherwise hold the caller
+ * @param {Promise} command - Pending command
+ * @param {number} timeout - Timeout in milliseconds
+ * @returns {Promise} Reply of the command
+ */
+function withTimeout(command, timeout) {
+  let timer;
+  const expired = new Promise((resolve, reject) => {
+    timer = setTimeout(() => reject(new Error(`Redis command timed out after ${timeout}ms`)), timeout);
+  });
+  return Promise.race([command, expired]).finally(() => clearTimeout(timer));
+}
+
+module.exports = {
+  createClient,
+  withTimeout
+};
@@ -8,6 +8,7 @@ const helmet = require('helmet');
 const cors = require('cors');
 const { createErrorResponse } = require('../dto/response-dtos');
 const logger = require('../utils/logger');
+const { withEmbedCors } = require('../utils/embedOrigins');
 
 /**
  * CORS Configuration
@@ -36,7 +37,9 @@ const corsOptions = {
     'Authorization',
     'X-API-Key',
     'X-Correlation-ID',
-    'X-Request-ID'
+    'X-Request-ID',
+    'X-Respondent-Token',
+    'X-Edit-Token'
   ],
   exposedHeaders: [
     'X-Correlation-ID',
@@ -451,7 +454,7 @@ module.exports = {
   // Combined security middleware
   applySecurity: (app) => {
     app.use(securityHeaders);
-    app.use(cors(corsOptions));
+    app.use(cors(withEmbedCors(corsOptions)));
     app.use(methodFilter);
     app.use(ipFilter);
     app.use(userAgentFilter);
@@ -70,6 +70,16 @@ class Response extends BaseModel {
     this.status = data.status || 'submitted'; // submitted, draft, flagged, deleted
     this.isDraft = data.isDraft || false;
     this.isComplete = data.isComplete !== undefined ? data.isComplete : true;
+    this.formVersion = data.formVersion || 1;
+    
+    // Order the options of randomized questions were shown in, by question ID
+    this.presentedOrder = data.presentedOrder || {};
+    
+    // Respondent edits; earlier revisions live in response_revisions
+    this.revision = data.revision || 1;
+    this.editTokenHash = data.editTokenHash || null;
+    this.editableUntil = data.editableUntil || null;
+    this.lastEditedAt = data.lastEditedAt || null;
     
     // Processing metadata
     this.processingStatus = data.processingStatus || 'pending'; // pending, processed, failed
@@ -100,6 +110,14 @@ class Response extends BaseModel {
     this.history = data.history || [];
   }
 
+  /**
+   * Serialize for API responses; the edit token hash never leaves the service
+   */
+  toJSON() {
+    const { editTokenHash, ...data } = this;
+    return data;
+  }
+
   /**
    * Validate response data
    */
@@ -0,0 +1,24 @@
+/**
+ * Internal Routes for Response Service
+ * Called by other services with the API key; not routed by the gateway.
+ * The totals of the gateway's admin dashboard are read without it, as from
+ * the other services.
+ */
+
+const express = require('express');
+const internalController = require('../../controllers/internalController');
+const { authenticateApiKey } = require('../../middleware/auth');
+const { asyncHandler } = require('../../middleware/errorHandler');
+
+const router = express.Router();
+
+router.get('/stats', asyncHandler(internalController.getStats));
+
+router.use(authenticateApiKey);
+
+// Bulk deletions of the form service: the responses matching a filter,
+// then their deletion by ID in batches
+router.post('/forms/:formId/responses/match', asyncHandler(internalController.matchResponses));
+router.post('/forms/:formId/responses/delete', asyncHandler(internalController.deleteResponses));
+
+module.exports = router;
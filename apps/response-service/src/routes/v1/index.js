@@ -92,10 +92,22 @@ router.get('/health/live', asyncHandler(healthController.getLiveness));
  *   post:
  *     tags: [Responses]
  *     summary: Submit a new form response
- *     description: Submit a new response to a form with validation and security checks
+ *     description: Submit a new response to a form with validation and security checks. Identified and one_per_user forms require a bearer token. The owner and collaborators of a published form submit test responses with X-Test-Submission or the test flag; test responses are stored flagged, and left out of quotas, deduplication, one response per user, analytics, exports and notifications.
  *     security:
  *       - bearerAuth: []
  *       - apiKeyAuth: []
+ *     parameters:
+ *       - in: header
+ *         name: X-Test-Submission
+ *         schema:
+ *           type: string
+ *           enum: ['true']
+ *         description: Submit a test response; requires a bearer token of the owner or a collaborator of the form
+ *       - in: query
+ *         name: test
+ *         schema:
+ *           type: boolean
+ *         description: Same as X-Test-Submission
  *     requestBody:
  *       required: true
  *       content:
@@ -125,12 +137,22 @@ router.get('/health/live', asyncHandler(healthController.getLiveness));
  *                   properties:
  *                     data:
  *                       $ref: '#/components/schemas/ResponseResponse'
+ *       200:
+ *         description: An identical submission by the same respondent was stored within the deduplication window; its response is returned with isDuplicate set and nothing is stored
  *       400:
  *         $ref: '#/components/responses/ValidationError'
  *       401:
- *         $ref: '#/components/responses/UnauthorizedError'
+ *         description: The form's response mode requires login, or a test submission carries no bearer token
+ *       402:
+ *         description: The organization owning the form has collected as many responses this month as its plan allows (UPGRADE_REQUIRED)
+ *       403:
+ *         description: The submission failed the spam protection of the form (CAPTCHA_REQUIRED, CAPTCHA_FAILED, HONEYPOT_FILLED, CHALLENGE_INVALID or SUBMISSION_TOO_FAST), or came from a site the form may not be embedded on, or carries the token of a form preview (PREVIEW_READ_ONLY), or is a test submission by someone other than the owner and collaborators of the published form (TEST_MODE_FORBIDDEN)
+ *       409:
+ *         description: The form accepts one response per user and the user has already responded
  *       429:
- *         $ref: '#/components/responses/RateLimitError'
+ *         description: Too many submissions from the client, or over the per-IP limit of a form throttled for a surge of submissions (THROTTLED_RATE_LIMITED)
+ *       503:
+ *         description: The CAPTCHA provider can't verify tokens (CAPTCHA_UNAVAILABLE)
  */
 router.post('/responses',
   submissionRateLimit,
@@ -286,16 +308,86 @@ router.get('/responses/:id',
   asyncHandler(responseController.getResponse)
 );
 
+/**
+ * @swagger
+ * /api/v1/responses/{id}/receipt.pdf:
+ *   get:
+ *     tags: [Responses]
+ *     summary: Download the receipt of a response
+ *     description: |
+ *       Download the PDF receipt of a submitted response: the form title, each
+ *       question with its answer, the submission time, the response ID and a
+ *       verification hash, also returned in X-Receipt-Hash. Respondents
+ *       download theirs as the signed-in original submitter or with the edit
+ *       token returned at submission; form owners and collaborators download
+ *       those of their forms.
+ *     security:
+ *       - bearerAuth: []
+ *       - apiKeyAuth: []
+ *       - {}
+ *     parameters:
+ *       - in: path
+ *         name: id
+ *         required: true
+ *         schema:
+ *           type: string
+ *           format: uuid
+ *         description: Response ID
+ *       - in: header
+ *         name: X-Edit-Token
+ *         schema:
+ *           type: string
+ *         description: Edit token returned when the response was submitted
+ *       - in: query
+ *         name: locale
+ *         schema:
+ *           type: string
+ *         description: Locale of the question titles; defaults to that of the response
+ *     responses:
+ *       200:
+ *         description: PDF receipt
+ *         headers:
+ *           X-Receipt-Hash:
+ *             schema:
+ *               type: string
+ *             description: Verification hash printed on the receipt
+ *         content:
+ *           application/pdf:
+ *             schema:
+ *               type: string
+ *               format: binary
+ *       404:
+ *         $ref: '#/components/responses/NotFoundError'
+ *       401:
+ *         $ref: '#/components/responses/UnauthorizedError'
+ *       403:
+ *         $ref: '#/components/responses/ForbiddenError'
+ *       409:
+ *         description: The response has not been submitted
+ */
+router.get('/responses/:id/receipt.pdf',
+  optionalAuthentication,
+  validateResponseId,
+  asyncHandler(responseController.getResponseReceipt)
+);
+
 /**
  * @swagger
  * /api/v1/responses/{id}:
  *   put:
  *     tags: [Responses]
  *     summary: Update a form response
- *     description: Update an existing form response (only allowed for drafts or by admin)
+ *     description: |
+ *       Update an existing form response. Drafts can be updated by their owner,
+ *       any response by an admin. During the form's edit window the respondent
+ *       may change the answers of a submitted response, either as the signed-in
+ *       original submitter or with the edit token returned at submission. The
+ *       edited answers are validated against the form version the response was
+ *       submitted under, and the replaced answers are kept as a revision.
  *     security:
  *       - bearerAuth: []
  *       - apiKeyAuth: []
+ *       - {}
  *     parameters:
  *       - in: path
  *         name: id
@@ -304,6 +396,11 @@ router.get('/responses/:id',
  *           type: string
  *           format: uuid
  *         description: Response ID
+ *       - in: header
+ *         name: X-Edit-Token
+ *         schema:
+ *           type: string
+ *         description: Edit token returned when the response was submitted
  *     requestBody:
  *       required: true
  *       content:
@@ -340,10 +437,12 @@ router.get('/responses/:id',
  *       401:
  *         $ref: '#/components/responses/UnauthorizedError'
  *       403:
- *         $ref: '#/components/responses/ForbiddenError'
+ *         description: Editing is disabled, the edit window has closed, or the caller is not the respondent
+ *       409:
+ *         description: The form version the response was submitted under is no longer available
  */
 router.put('/responses/:id',
-  authenticate,
+  optionalAuthentication,
   validateResponseId,
   validateContentType(['application/json', 'multipart/form-data']),
   sanitizeInput,
@@ -515,6 +614,24 @@ router.get('/forms/:formId/responses/analytics',
  *           type: boolean
  *           default: false
  *         description: Include metadata in export
+ *       - in: query
+ *         name: includeRevisions
+ *         schema:
+ *           type: boolean
+ *           default: false
+ *         description: Include the earlier revisions of edited responses
+ *       - in: query
+ *         name: includePresentedOrder
+ *         schema:
+ *           type: boolean
+ *           default: false
+ *         description: Add the order the options of randomized questions were shown in
+ *       - in: query
+ *         name: includeTest
+ *         schema:
+ *           type: boolean
+ *           default: false
+ *         description: Include test responses, left out by default
  *     responses:
  *       200:
  *         description: Export file
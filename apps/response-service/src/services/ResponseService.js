@@ -2,8 +2,15 @@ const { firestore, FieldValue } = require('../config/firebase');
 const { Response, ResponseAnalytics, FormIntegration } = require('../models');
 const { PaginationHelper, DateHelper, ErrorHelper } = require('../utils/helpers');
 const { customValidators } = require('../validators');
+const formServiceIntegration = require('../integrations/formService');
+const eventBusIntegration = require('../integrations/eventBus');
+const responseModes = require('../utils/responseModes');
+const responseEdits = require('../utils/responseEdits');
 const logger = require('../utils/logger');
 
+// gRPC status code Firestore returns when creating a document that exists
+const FIRESTORE_ALREADY_EXISTS = 6;
+
 /**
  * Response Service - Core business logic for form responses
  */
@@ -12,22 +19,45 @@ class ResponseService {
     this.collection = firestore.collection('responses');
     this.analyticsCollection = firestore.collection('response_analytics');
     this.integrationsCollection = firestore.collection('form_integrations');
+    // One document per (formId, userId) of one_per_user forms, acting as a
+    // unique index over responses
+    this.submittersCollection = firestore.collection('response_submitters');
+    // Answers replaced by respondent edits, one document per revision
+    this.revisionsCollection = firestore.collection('response_revisions');
   }
 
   /**
    * Create a new response
    * @param {Object} responseData - Response data
    * @param {Object} formSchema - Form schema for validation
-   * @param {Object} metadata - Additional metadata
+   * @param {Object} metadata - Additional metadata; user is the verified JWT of the respondent
+   * @returns {Promise<Response>} The saved response. When the form allows edits,
+   * its non-enumerable editToken must be handed to the respondent; it is not stored.
    */
   async createResponse(responseData, formSchema = null, metadata = {}) {
     try {
+      // Timing beacons only feed the analytics projection; they are published
+      // with the response but never stored on it
+      const { timings, ...submission } = responseData;
+
+      // Enforce the form's response mode before anything is stored
+      const policy = responseModes.resolveResponsePolicy(formSchema?.settings);
+      const respondent = responseModes.resolveRespondent(policy, metadata.user);
+      const { ipAddress, userAgent } = responseModes.applyMetadataPolicy(policy, metadata);
+
+      // Forms with an edit window hand out an edit token; only its hash is kept
+      const editToken = policy.editWindowMinutes ? responseEdits.issueEditToken() : null;
+
       // Create response model
       const response = new Response({
-        ...responseData,
-        ipAddress: metadata.ipAddress,
-        userAgent: metadata.userAgent,
+        ...submission,
+        ...respondent,
+        ipAddress,
+        userAgent,
         sessionId: metadata.sessionId,
+        formVersion: formSchema?.version,
+        editTokenHash: editToken?.hash,
+        editableUntil: responseEdits.editableUntil(policy),
       });
 
       // Validate basic response structure
@@ -36,12 +66,32 @@ class ResponseService {
         throw ErrorHelper.createError('Response validation failed', 400, 'VALIDATION_ERROR');
       }
 
+      // Reserve the document ID up front so uploaded files can be attached to it
+      const docRef = this.collection.doc();
+      response.id = docRef.id;
+
       // Validate response answers against form schema
       if (formSchema && response.responses) {
         const answerValidation = await customValidators.validateResponseAnswers(
           response.responses,
           formSchema
         );
+
+        const orderValidation = customValidators.validatePresentedOrder(response.presentedOrder, formSchema);
+        answerValidation.errors.push(...orderValidation.errors);
+        answerValidation.isValid = answerValidation.isValid && orderValidation.isValid;
+
+        if (answerValidation.isValid) {
+          const fileErrors = await this._verifyFileAnswers(response, formSchema, metadata.correlationId);
+          answerValidation.errors.push(...fileErrors);
+          answerValidation.isValid = fileErrors.length === 0;
+        }
+
+        if (answerValidation.isValid) {
+          const ruleErrors = await this._checkRules(response, formSchema, metadata);
+          answerValidation.errors.push(...ruleErrors);
+          answerValidation.isValid = ruleErrors.length === 0;
+        }
         
         if (!answerValidation.isValid) {
           response.validationResults = {
@@ -73,9 +123,27 @@ class ResponseService {
         formVersion: formSchema?.version,
       });
 
-      // Save to Firestore
-      const docRef = await this.collection.add(response.toFirestore());
-      response.id = docRef.id;
+      // Save to Firestore. For one_per_user forms the submitter document is
+      // created in the same batch, so a second submission fails atomically.
+      const batch = firestore.batch();
+      if (policy.oneResponsePerUser) {
+        batch.create(this._submitterRef(response.formId, response.submitterId), {
+          formId: response.formId,
+          userId: response.submitterId,
+          responseId: response.id,
+          createdAt: FieldValue.serverTimestamp(),
+        });
+      }
+      batch.set(docRef, response.toFirestore());
+
+      try {
+        await batch.commit();
+      } catch (error) {
+        if (policy.oneResponsePerUser && error.code === FIRESTORE_ALREADY_EXISTS) {
+          throw responseModes.duplicateSubmissionError();
+        }
+        throw error;
+      }
 
       logger.info('Response created', {
         responseId: response.id,
@@ -95,13 +163,112 @@ class ResponseService {
         logger.error('Failed to trigger integrations for new response:', error);
       });
 
+      // The submitted response supersedes the respondent's autosaved draft
+      if (!response.isDraft) {
+        formServiceIntegration.consumeDraft(response.formId, {
+          userId: metadata.user ? responseModes.getAuthenticatedUserId(metadata.user) : null,
+          respondentToken: metadata.respondentToken,
+        }, metadata.correlationId).catch(error => {
+          logger.error('Failed to consume response draft:', error);
+        });
+      }
+
+      // Publish to the event bus for the analytics projection
+      eventBusIntegration.publishResponseCreated(response, formSchema, { ...metadata, timings }).catch(error => {
+        logger.error('Failed to publish response created event:', error);
+      });
+
+      if (editToken) {
+        Object.defineProperty(response, 'editToken', { value: editToken.token, enumerable: false });
+      }
+
       return response;
     } catch (error) {
+      if (error.status) {
+        throw error;
+      }
       logger.error('Failed to create response:', error);
       throw ErrorHelper.handleDbError(error);
     }
   }
 
+  /**
+   * Reference to the submitter document of a user on a form
+   * @param {string} formId - Form ID
+   * @param {string} userId - User ID
+   */
+  _submitterRef(formId, userId) {
+    return this.submittersCollection.doc(`${formId}_${userId}`);
+  }
+
+  /**
+   * Verify file answers with the form service, which checks each token against
+   * the uploaded object and attaches it to the response
+   * @param {Object} response - Response being created
+   * @param {Object} formSchema - Form schema
+   * @param {string} correlationId - Request correlation ID
+   * @returns {Promise<Array>} Validation errors
+   */
+  async _verifyFileAnswers(response, formSchema, correlationId) {
+    const errors = [];
+    const fileQuestions = (formSchema.questions || []).filter(question => question.type === 'file');
+
+    for (const question of fileQuestions) {
+      const answer = response.responses[question.id];
+      if (!answer) {
+        continue;
+      }
+
+      const tokens = Array.isArray(answer) ? answer : [answer];
+      const result = await formServiceIntegration.verifyFileTokens(
+        response.formId,
+        question.id,
+        tokens,
+        response.id,
+        correlationId
+      );
+
+      if (!result.isValid) {
+        errors.push({
+          questionId: question.id,
+          field: question.question || question.title,
+          message: result.error,
+          value: answer,
+        });
+      }
+    }
+
+    return errors;
+  }
+
+  /**
+   * Check the answers against the cross-field rules of the form, which the
+   * form service evaluates so that the rules are enforced the same everywhere
+   * @param {Object} response - Response being created
+   * @param {Object} formSchema - Form schema
+   * @param {Object} metadata - Request metadata; locale picks the language of the messages
+   * @returns {Promise<Array>} Validation errors
+   */
+  async _checkRules(response, formSchema, metadata) {
+    // Schemas known to have no rules spare the call
+    if (Array.isArray(formSchema.rules) && formSchema.rules.length === 0) {
+      return [];
+    }
+
+    const broken = await formServiceIntegration.checkResponseRules(
+      response.formId,
+      response.responses,
+      metadata.locale,
+      metadata.correlationId
+    );
+
+    return broken.map(rule => ({
+      ruleId: rule.rule_id,
+      questionIds: rule.question_ids,
+      message: rule.message,
+    }));
+  }
+
   /**
    * Update an existing response
    * @param {string} responseId - Response ID
@@ -170,6 +337,113 @@ class ResponseService {
     }
   }
 
+  /**
+   * Edit the answers of a submitted response as its respondent. The edit must
+   * fall inside the form's edit window and the answers are validated against
+   * the form version the response was submitted under. The replaced answers
+   * are stored as a revision in the same transaction.
+   * @param {string} responseId - Response ID
+   * @param {Object} answers - New answers keyed by question ID
+   * @param {Object} credentials - { user, editToken } of the caller
+   * @param {Object} metadata - Request metadata
+   */
+  async editResponse(responseId, answers, credentials = {}, metadata = {}) {
+    try {
+      const docRef = this.collection.doc(responseId);
+      const doc = await docRef.get();
+
+      if (!doc.exists) {
+        throw ErrorHelper.createError('Response not found', 404, 'RESPONSE_NOT_FOUND');
+      }
+
+      const current = Response.fromFirestore(doc);
+      responseEdits.authorizeEdit({
+        editableUntil: current.editableUntil,
+        editTokenHash: current.editTokenHash,
+        ownerId: current.submitterId,
+      }, credentials);
+
+      const schema = await responseEdits.getSubmittedSchema(current.formId, current.formVersion, metadata.correlationId);
+      await responseEdits.validateEditedAnswers(answers, schema);
+
+      // Re-check inside the transaction so concurrent edits can't both
+      // supersede the same revision
+      const response = await firestore.runTransaction(async transaction => {
+        const latest = Response.fromFirestore(await transaction.get(docRef));
+        const editor = responseEdits.authorizeEdit({
+          editableUntil: latest.editableUntil,
+          editTokenHash: latest.editTokenHash,
+          ownerId: latest.submitterId,
+        }, credentials);
+
+        const editedAt = new Date().toISOString();
+        const revision = responseEdits.buildRevision(latest, editor, editedAt);
+        transaction.create(
+          this.revisionsCollection.doc(`${latest.id}_${revision.revision}`),
+          revision
+        );
+
+        latest.responses = answers;
+        latest.revision = revision.revision + 1;
+        latest.lastEditedAt = editedAt;
+        latest.validationResults = {
+          isValid: true,
+          errors: [],
+          validatedAt: FieldValue.serverTimestamp(),
+        };
+        latest.touch();
+        latest.addToHistory('edited', editor.editorId, {
+          revision: latest.revision,
+          method: editor.method,
+        });
+
+        transaction.update(docRef, latest.toFirestore());
+        return latest;
+      });
+
+      logger.info('Response edited', {
+        responseId,
+        formId: response.formId,
+        revision: response.revision,
+      });
+
+      this._triggerIntegrations(response, 'updated').catch(error => {
+        logger.error('Failed to trigger integrations for edited response:', error);
+      });
+
+      eventBusIntegration.publishResponseUpdated(response, schema, metadata).catch(error => {
+        logger.error('Failed to publish response updated event:', error);
+      });
+
+      return response;
+    } catch (error) {
+      if (error.status) {
+        throw error;
+      }
+      logger.error('Failed to edit response:', error);
+      throw ErrorHelper.handleDbError(error);
+    }
+  }
+
+  /**
+   * Get the earlier revisions of a response, oldest first
+   * @param {string} responseId - Response ID
+   * @returns {Promise<Array>} Revision records
+   */
+  async getRevisions(responseId) {
+    try {
+      const snapshot = await this.revisionsCollection
+        .where('responseId', '==', responseId)
+        .orderBy('revision', 'asc')
+        .get();
+
+      return snapshot.docs.map(doc => doc.data());
+    } catch (error) {
+      logger.error('Failed to get response revisions:', error);
+      throw ErrorHelper.handleDbError(error);
+    }
+  }
+
   /**
    * Get response by ID
    * @param {string} responseId - Response ID
@@ -320,8 +594,17 @@ class ResponseService {
       }
 
       if (permanent) {
-        // Permanent deletion
-        await docRef.delete();
+        // Permanent deletion also frees the respondent's one_per_user slot
+        // and removes the revision history
+        const { formId, submitterId } = doc.data();
+        const revisions = await this.revisionsCollection.where('responseId', '==', responseId).get();
+        const batch = firestore.batch();
+        batch.delete(docRef);
+        if (formId && submitterId) {
+          batch.delete(this._submitterRef(formId, submitterId));
+        }
+        revisions.docs.forEach(revision => batch.delete(revision.ref));
+        await batch.commit();
         
         logger.info('Response permanently deleted', {
           responseId,
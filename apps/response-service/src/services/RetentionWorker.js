@@ -0,0 +1,342 @@
+/**
+ * Retention Worker
+ * Purges the responses of forms with a retention period (retention_days,
+ * set in the form service) once they are older than it. Responses are
+ * anonymized, losing their respondent, metadata and revisions but keeping
+ * their answers for analytics, or deleted, as RETENTION_MODE says.
+ *
+ * The worker runs daily at RETENTION_RUN_AT (UTC). Every instance schedules
+ * it; the instance taking the Redis lock runs it, renewing the lock as it
+ * goes. While Redis is unavailable no instance runs, rather than several.
+ * Responses are purged in batches with a pause in between, so a run doesn't
+ * monopolize the store. Each form purged gets a form.response.purged event,
+ * for projections to follow, and an audit entry.
+ */
+
+const crypto = require('crypto');
+const config = require('../config/enhanced');
+const logger = require('../utils/logger');
+const metrics = require('../utils/metrics');
+const eventBus = require('../integrations/eventBus');
+const { createClient } = require('../integrations/redis');
+
+const LOCK_KEY = 'response-service:retention:leader';
+const DAY_MS = 24 * 60 * 60 * 1000;
+
+// Extends the lock while it is still held by the caller
+const RENEW_SCRIPT = "if redis.call('GET', KEYS[1]) == ARGV[1] then return redis.call('PEXPIRE', KEYS[1], ARGV[2]) else return 0 end";
+// Deletes the lock while it is still held by the caller
+const RELEASE_SCRIPT = "if redis.call('GET', KEYS[1]) == ARGV[1] then return redis.call('DEL', KEYS[1]) else return 0 end";
+
+// Metadata kept on anonymized responses; the rest may identify respondents
+const KEPT_METADATA = ['submissionSource'];
+
+class RetentionWorker {
+  /**
+   * @param {Object} options
+   * @param {Object} options.store - Store with responses, forms, submitters
+   *   and revisions maps
+   * @param {Object} [options.redis] - Client of the leader lock
+   * @param {Object} [options.eventBus] - Publisher of purge events
+   * @param {Object} [options.settings] - The retention configuration
+   * @param {Function} [options.now] - Clock, for tests
+   */
+  constructor({ store, redis, eventBus: publisher = eventBus, settings = config.get('retention'), now = () => new Date() }) {
+    this.store = store;
+    this.redis = redis;
+    this.eventBus = publisher;
+    this.settings = settings;
+    this.now = now;
+    this.instanceId = crypto.randomUUID();
+    this.timer = null;
+    this.running = null;
+    this.stopping = false;
+    // Whether this instance holds the lock, null before the first run
+    this.held = null;
+  }
+
+  /**
+   * Schedule the daily runs
+   */
+  start() {
+    if (!this.settings.enabled) {
+      logger.info('Response retention disabled');
+      return;
+    }
+    this.stopping = false;
+    this.schedule();
+  }
+
+  /**
+   * Stop scheduling runs and wait for the current one to stop after its
+   * batch
+   */
+  async stop() {
+    this.stopping = true;
+    clearTimeout(this.timer);
+    this.timer = null;
+    await this.running;
+    if (this.redis?.isOpen) {
+      await this.redis.disconnect();
+    }
+  }
+
+  /**
+   * Schedule the next run at settings.runAt
+   */
+  schedule() {
+    const delay = this.nextRunAt().getTime() - this.now().getTime();
+    this.timer = setTimeout(async () => {
+      this.running = this.run().catch(error => {
+        logger.error('Response retention run failed', { error: error.message, stack: error.stack });
+      });
+      await this.running;
+      this.running = null;
+      if (!this.stopping) {
+        this.schedule();
+      }
+    }, delay);
+    // The schedule alone doesn't keep the process alive
+    this.timer.unref();
+  }
+
+  /**
+   * The next time of day settings.runAt, in UTC
+   * @returns {Date}
+   */
+  nextRunAt() {
+    const [hours, minutes] = this.settings.runAt.split(':').map(Number);
+    const now = this.now();
+    const next = new Date(Date.UTC(now.getUTCFullYear(), now.getUTCMonth(), now.getUTCDate(), hours, minutes));
+    if (next <= now) {
+      next.setUTCDate(next.getUTCDate() + 1);
+    }
+    return next;
+  }
+
+  /**
+   * Run once if this instance takes the lock
+   * @param {Object} [options]
+   * @param {boolean} [options.dryRun] - Count the responses to purge without
+   *   purging them; defaults to settings.dryRun
+   * @returns {Promise<Object|null>} Summary of the run, null when another
+   *   instance runs it
+   */
+  async run({ dryRun = this.settings.dryRun } = {}) {
+    if (!(await this.acquire())) {
+      return null;
+    }
+    const renewal = setInterval(() => this.renew(), Math.floor(this.settings.lockTtlMs / 3));
+    renewal.unref();
+    try {
+      return await this.purge(dryRun);
+    } finally {
+      clearInterval(renewal);
+      await this.release();
+    }
+  }
+
+  /**
+   * Purge the responses older than the retention of their form
+   * @param {boolean} dryRun - Count without purging
+   * @returns {Promise<Object>} runId, mode, dryRun, purged count and forms
+   */
+  async purge(dryRun) {
+    const startedAt = Date.now();
+    const runId = this.now().toISOString();
+    const { mode, batchSize, batchDelayMs, maxPerRun } = this.settings;
+    const summary = { runId, mode, dryRun, purged: 0, forms: [] };
+
+    for (const form of this.store.forms.values()) {
+      const retentionDays = form.retentionDays ?? form.retention_days;
+      if (!retentionDays) {
+        continue;
+      }
+      const purgedBefore = new Date(this.now().getTime() - retentionDays * DAY_MS);
+      const allExpired = this.expiredResponses(form.id, purgedBefore);
+      const expired = maxPerRun > 0 ? allExpired.slice(0, maxPerRun - summary.purged) : allExpired;
+      if (expired.length === 0) {
+        continue;
+      }
+
+      let count = 0;
+      if (dryRun) {
+        count = expired.length;
+      } else {
+        for (let i = 0; i < expired.length && !this.stopping && this.held !== false; i += batchSize) {
+          if (i > 0) {
+            await sleep(batchDelayMs);
+          }
+          for (const response of expired.slice(i, i + batchSize)) {
+            this.purgeResponse(response, mode);
+          }
+          count += Math.min(batchSize, expired.length - i);
+        }
+      }
+
+      // A form purged in part moves the cutoff of its event to the last
+      // response purged, for projections to purge the same rows
+      const cutoff = count > 0 && count < allExpired.length
+        ? new Date(new Date(expired[count - 1].submittedAt).getTime() + 1)
+        : purgedBefore;
+      const purge = { formId: form.id, runId, mode, count, retentionDays, purgedBefore: cutoff.toISOString() };
+      summary.forms.push(purge);
+      summary.purged += count;
+      logger.info(dryRun ? 'Responses to purge for retention' : 'Responses purged for retention', { ...purge, dryRun });
+      if (!dryRun && count > 0) {
+        await this.recordPurge(purge);
+      }
+
+      if (this.stopping || this.held === false || (maxPerRun > 0 && summary.purged >= maxPerRun)) {
+        break;
+      }
+    }
+
+    metrics.retentionRunDuration.set({ dry_run: dryRun }, (Date.now() - startedAt) / 1000);
+    logger.info('Response retention run completed', {
+      runId,
+      mode,
+      dryRun,
+      purged: summary.purged,
+      forms: summary.forms.length,
+      durationMs: Date.now() - startedAt
+    });
+    return summary;
+  }
+
+  /**
+   * The responses of a form submitted before a time that are not purged yet
+   * @param {string} formId - Form ID
+   * @param {Date} before - Cutoff
+   * @returns {Object[]} Oldest first
+   */
+  expiredResponses(formId, before) {
+    return Array.from(this.store.responses.values())
+      .filter(response => response.formId === formId && !response.purgedAt && new Date(response.submittedAt) < before)
+      .sort((a, b) => new Date(a.submittedAt) - new Date(b.submittedAt));
+  }
+
+  /**
+   * Anonymize or delete a response, with its revisions and the record of
+   * its respondent
+   * @param {Object} response - Response
+   * @param {string} mode - anonymize or delete
+   */
+  purgeResponse(response, mode) {
+    this.store.revisions.delete(response.id);
+    this.store.submitters.delete(`${response.formId}:${response.respondentId}`);
+    if (mode === 'delete') {
+      this.store.responses.delete(response.id);
+      return;
+    }
+    const metadata = Object.fromEntries(
+      Object.entries(response.metadata || {}).filter(([key]) => KEPT_METADATA.includes(key))
+    );
+    this.store.responses.set(response.id, {
+      ...response,
+      respondentId: null,
+      respondentEmail: null,
+      respondentName: null,
+      isAnonymous: true,
+      sessionId: null,
+      metadata,
+      editTokenHash: null,
+      editableUntil: null,
+      purgedAt: this.now().toISOString()
+    });
+  }
+
+  /**
+   * Publish the purge event and audit entry of a form. Failures are logged:
+   * the responses are purged either way.
+   * @param {Object} purge - The purge of the form
+   */
+  async recordPurge(purge) {
+    metrics.purgedResponses.inc({ form_id: purge.formId, mode: purge.mode }, purge.count);
+    try {
+      await this.eventBus.publishResponsesPurged(purge);
+      await this.eventBus.publishPurgeAudit(purge);
+    } catch (error) {
+      logger.error('Failed to publish response purge', { formId: purge.formId, runId: purge.runId, error: error.message });
+    }
+  }
+
+  /**
+   * Take the leader lock. Without Redis no instance runs.
+   * @returns {Promise<boolean>} Whether this instance holds the lock
+   */
+  async acquire() {
+    if (!this.redis) {
+      this.held = true;
+      return true;
+    }
+    try {
+      this.held = Boolean(await this.redis.set(LOCK_KEY, this.instanceId, { NX: true, PX: this.settings.lockTtlMs }));
+    } catch (error) {
+      logger.warn('Response retention lock unavailable, skipping the run', { error: error.message });
+      this.held = false;
+    }
+    if (!this.held) {
+      logger.info('Response retention run left to another instance');
+    }
+    return this.held;
+  }
+
+  /**
+   * Extend the lock; losing it stops the run after its batch
+   */
+  async renew() {
+    if (!this.redis) {
+      return;
+    }
+    try {
+      this.held = (await this.redis.eval(RENEW_SCRIPT, {
+        keys: [LOCK_KEY],
+        arguments: [this.instanceId, String(this.settings.lockTtlMs)]
+      })) === 1;
+    } catch (error) {
+      this.held = false;
+    }
+    if (!this.held) {
+      logger.warn('Response retention lock lost, stopping the run');
+    }
+  }
+
+  /**
+   * Release the lock if still held
+   */
+  async release() {
+    if (!this.redis) {
+      return;
+    }
+    try {
+      await this.redis.eval(RELEASE_SCRIPT, { keys: [LOCK_KEY], arguments: [this.instanceId] });
+    } catch (error) {
+      logger.warn('Failed to release the response retention lock', { error: error.message });
+    }
+  }
+}
+
+/**
+ * Sleep between batches
+ * @param {number} ms - Milliseconds
+ */
+const sleep = ms => new Promise(resolve => setTimeout(resolve, ms));
+
+/**
+ * The worker of the responses of the controllers, locking with the cache's
+ * Redis
+ * @param {Object} store - Store of the responses
+ * @returns {RetentionWorker}
+ */
+function createRetentionWorker(store) {
+  return new RetentionWorker({
+    store,
+    redis: createClient('retention', 2000)
+  });
+}
+
+module.exports = {
+  RetentionWorker,
+  createRetentionWorker
+};
@@ -5,6 +5,7 @@ const fs = require('fs');
 const { promisify } = require('util');
 const { DateHelper } = require('./helpers');
 const logger = require('./logger');
+const { isAnonymized } = require('./responseModes');
 
 const writeFile = promisify(fs.writeFile);
 const mkdir = promisify(fs.mkdir);
@@ -41,11 +42,21 @@ class CsvExporter {
         filename = this.generateFilename(form.title || 'responses'),
         includeMetadata = true,
         customHeaders = null,
+        includeRevisions = false,
       } = options;
 
       const filePath = path.join(this.exportDir, filename);
       const headers = this.generateHeaders(form, responses, customHeaders);
-      const records = this.transformResponses(responses, form, includeMetadata);
+      let records;
+      if (includeRevisions) {
+        headers.splice(1, 0,
+          { id: 'revision', title: 'Revision' },
+          { id: 'supersededAt', title: 'Superseded At' }
+        );
+        records = this.transformResponsesWithRevisions(responses, form, includeMetadata);
+      } else {
+        records = this.transformResponses(responses, form, includeMetadata);
+      }
 
       const csvWriter = createCsvWriter({
         path: filePath,
@@ -218,6 +229,36 @@ class CsvExporter {
     );
   }
 
+  /**
+   * Transform responses to CSV records, following each response with one
+   * record per earlier revision. Responses carry their revisions in a
+   * revisions array, as returned by ResponseService.getRevisions.
+   * @param {Array} responses - Array of response objects
+   * @param {Object} form - Form metadata
+   * @param {boolean} includeMetadata - Whether to include metadata
+   */
+  transformResponsesWithRevisions(responses, form, includeMetadata = true) {
+    const records = [];
+
+    responses.forEach(response => {
+      records.push({
+        ...this.transformResponse(response, form, includeMetadata),
+        revision: response.revision || 1,
+        supersededAt: '',
+      });
+
+      (response.revisions || []).forEach(revision => {
+        records.push({
+          ...this.transformResponse({ ...response, responses: revision.answers }, form, includeMetadata),
+          revision: revision.revision,
+          supersededAt: revision.editedAt || '',
+        });
+      });
+    });
+
+    return records;
+  }
+
   /**
    * Transform single response to CSV record
    * @param {Object} response - Response object
@@ -232,8 +273,10 @@ class CsvExporter {
       record.responseId = response.id;
       record.submittedAt = response.submittedAt ? 
         new Date(response.submittedAt.toDate()).toISOString() : '';
-      record.submitterEmail = response.submitterEmail || '';
-      record.submitterName = response.submitterName || '';
+      // Anonymous responses never export the respondent identity
+      const anonymized = isAnonymized(response);
+      record.submitterEmail = anonymized ? '' : response.submitterEmail || '';
+      record.submitterName = anonymized ? '' : response.submitterName || '';
       record.ipAddress = response.metadata?.ipAddress || '';
       record.userAgent = response.metadata?.userAgent || '';
       record.duration = response.metadata?.duration || '';
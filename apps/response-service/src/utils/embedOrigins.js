@@ -0,0 +1,78 @@
+/**
+ * Embedded form submissions
+ * Forms embedded on other sites submit to POST /api/v1/responses from those
+ * sites. CORS lets any origin reach the endpoint, without credentials; each
+ * submission is then checked against the embed_allowed_origins of its form.
+ */
+
+const { createError } = require('../middleware/errorHandler');
+
+const EMBED_SUBMIT_PATH = '/api/v1/responses';
+
+/**
+ * Whether a request is a cross-origin submission, or its preflight
+ * @param {Object} req - Express request
+ * @returns {boolean}
+ */
+function isEmbedSubmission(req) {
+  return req.path === EMBED_SUBMIT_PATH &&
+    (req.method === 'POST' || req.method === 'OPTIONS') &&
+    Boolean(req.get('Origin'));
+}
+
+/**
+ * Wrap CORS options so that submissions are let in from any origin without
+ * credentials, leaving the origin check to checkSubmissionOrigin
+ * @param {Object} options - Options of the cors middleware
+ * @returns {Function} Options delegate of the cors middleware
+ */
+function withEmbedCors(options) {
+  return (req, callback) => {
+    if (isEmbedSubmission(req)) {
+      callback(null, { ...options, origin: true, credentials: false });
+      return;
+    }
+    callback(null, options);
+  };
+}
+
+/**
+ * Normalize an origin to scheme://host[:port] in lowercase, as the form
+ * service stores embed origins
+ * @param {string} origin - Value of an Origin header
+ * @returns {string|null} Normalized origin, null when it isn't one
+ */
+function normalizeOrigin(origin) {
+  try {
+    const url = new URL(origin);
+    return `${url.protocol}//${url.host}`.toLowerCase();
+  } catch (error) {
+    return null;
+  }
+}
+
+/**
+ * Reject submissions from sites the form may not be embedded on. Requests
+ * without an Origin header and those from the first-party frontend pass.
+ * @param {Object} settings - Form settings
+ * @param {string} origin - Value of the Origin header, if any
+ * @param {string[]} firstPartyOrigins - Origins of the frontend
+ * @throws {Error} 403 when the origin is not allow-listed on the form
+ */
+function checkSubmissionOrigin(settings, origin, firstPartyOrigins = []) {
+  if (!origin || firstPartyOrigins.includes(origin)) {
+    return;
+  }
+
+  const allowed = settings?.embed_allowed_origins || settings?.embedAllowedOrigins || [];
+  if (!allowed.includes(normalizeOrigin(origin))) {
+    throw createError('This site is not allowed to submit responses to the form', 403, 'EMBED_ORIGIN_NOT_ALLOWED');
+  }
+}
+
+module.exports = {
+  isEmbedSubmission,
+  withEmbedCors,
+  normalizeOrigin,
+  checkSubmissionOrigin
+};
@@ -0,0 +1,120 @@
+/**
+ * Prometheus metrics
+ * Counters and gauges kept in memory and rendered in the Prometheus text
+ * format on GET /metrics
+ */
+
+const metrics = [];
+
+/**
+ * Escape a label value for the text format
+ */
+const escapeLabel = value => String(value).replace(/\\/g, '\\\\').replace(/\n/g, '\\n').replace(/"/g, '\\"');
+
+/**
+ * Key of the values of a metric for labels
+ */
+const labelKey = (labelNames, labels) => JSON.stringify(labelNames.map(label => labels[label] ?? ''));
+
+/**
+ * Create and register a counter
+ * @param {string} name - Metric name
+ * @param {string} help - Metric description
+ * @param {string[]} labelNames - Label names, in order
+ * @returns {Object} Counter with inc(labels, value)
+ */
+function counter(name, help, labelNames = []) {
+  const values = new Map();
+  const metric = {
+    name,
+    help,
+    inc(labels = {}, value = 1) {
+      const key = labelKey(labelNames, labels);
+      values.set(key, (values.get(key) || 0) + value);
+    },
+    render: () => renderValues(name, help, 'counter', labelNames, values)
+  };
+  metrics.push(metric);
+  return metric;
+}
+
+/**
+ * Create and register a gauge
+ * @param {string} name - Metric name
+ * @param {string} help - Metric description
+ * @param {string[]} labelNames - Label names, in order
+ * @returns {Object} Gauge with set(labels, value)
+ */
+function gauge(name, help, labelNames = []) {
+  const values = new Map();
+  const metric = {
+    name,
+    help,
+    set(labels = {}, value) {
+      values.set(labelKey(labelNames, labels), value);
+    },
+    render: () => renderValues(name, help, 'gauge', labelNames, values)
+  };
+  metrics.push(metric);
+  return metric;
+}
+
+/**
+ * Render the values of a metric in the text format
+ */
+function renderValues(name, help, type, labelNames, values) {
+  const lines = [`# HELP ${name} ${help}`, `# TYPE ${name} ${type}`];
+  for (const [key, value] of values) {
+    const labels = JSON.parse(key)
+      .map((labelValue, i) => `${labelNames[i]}="${escapeLabel(labelValue)}"`)
+      .join(',');
+    lines.push(`${name}${labels ? `{${labels}}` : ''} ${value}`);
+  }
+  return lines.join('\n');
+}
+
+/**
+ * Render every metric in the Prometheus text format
+ * @returns {string}
+ */
+function render() {
+  return `${metrics.map(metric => metric.render()).join('\n')}\n`;
+}
+
+// Submissions rejected by the spam protection of their form, by reason
+const spamBlockedSubmissions = counter(
+  'response_service_spam_blocked_submissions_total',
+  'Submissions blocked by the spam protection of their form',
+  ['form_id', 'reason']
+);
+
+// Identical submissions answered with the response of the first, by form
+const deduplicatedSubmissions = counter(
+  'response_service_deduplicated_submissions_total',
+  'Submissions deduplicated as identical to a recent submission',
+  ['form_id']
+);
+
+// Responses purged by the retention worker, by form and mode
+const purgedResponses = counter(
+  'response_service_retention_purged_responses_total',
+  'Responses anonymized or deleted for being older than the retention of their form',
+  ['form_id', 'mode']
+);
+
+// Duration of the last retention run, in seconds
+const retentionRunDuration = gauge(
+  'response_service_retention_last_run_duration_seconds',
+  'Duration of the last run of the retention worker',
+  ['dry_run']
+);
+
+module.exports = {
+  counter,
+  gauge,
+  render,
+  spamBlockedSubmissions,
+  deduplicatedSubmissions,
+  purgedResponses,
+  retentionRunDuration
+};
@@ -0,0 +1,179 @@
+/**
+ * Response editing
+ * Lets respondents edit a submitted response during the form's edit window.
+ * Every edit keeps the previous answers as a revision.
+ */
+
+const crypto = require('crypto');
+const { createError } = require('../middleware/errorHandler');
+const { customValidators } = require('../validators');
+const formServiceIntegration = require('../integrations/formService');
+const { getAuthenticatedUserId } = require('./responseModes');
+
+// Header carrying the edit token returned when the response was submitted
+const EDIT_TOKEN_HEADER = 'X-Edit-Token';
+
+/**
+ * Issue an edit token for a new response. Only the hash is stored; the token
+ * itself is returned to the respondent once.
+ * @returns {Object} { token, hash }
+ */
+function issueEditToken() {
+  const token = crypto.randomBytes(32).toString('base64url');
+  return { token, hash: hashEditToken(token) };
+}
+
+/**
+ * Hash an edit token for storage and comparison
+ * @param {string} token - Edit token
+ * @returns {string} Hex encoded SHA-256
+ */
+function hashEditToken(token) {
+  return crypto.createHash('sha256').update(String(token)).digest('hex');
+}
+
+/**
+ * Check an edit token against the hash stored with a response, in constant
+ * time
+ * @param {string} editTokenHash - Hash stored with the response
+ * @param {string} editToken - Edit token given by the caller
+ * @returns {boolean}
+ */
+function holdsEditToken(editTokenHash, editToken) {
+  if (!editToken || !editTokenHash) {
+    return false;
+  }
+  const given = Buffer.from(hashEditToken(editToken), 'hex');
+  const stored = Buffer.from(editTokenHash, 'hex');
+  return given.length === stored.length && crypto.timingSafeEqual(given, stored);
+}
+
+/**
+ * Convert a Date, ISO string or Firestore Timestamp to a Date
+ * @param {*} value - Date value
+ * @returns {Date|null}
+ */
+function toDate(value) {
+  if (!value) {
+    return null;
+  }
+  return typeof value.toDate === 'function' ? value.toDate() : new Date(value);
+}
+
+/**
+ * Compute until when a response submitted at submittedAt may be edited
+ * @param {Object} policy - Policy from resolveResponsePolicy
+ * @param {Date} submittedAt - Submission time
+ * @returns {string|null} ISO timestamp, or null when editing is disabled
+ */
+function editableUntil(policy, submittedAt = new Date()) {
+  if (!policy.editWindowMinutes) {
+    return null;
+  }
+  return new Date(toDate(submittedAt).getTime() + policy.editWindowMinutes * 60 * 1000).toISOString();
+}
+
+/**
+ * Check that the caller may edit a response as its respondent: the window
+ * must be open and the caller must either be the authenticated original
+ * submitter or hold the edit token.
+ * @param {Object} target - { editableUntil, editTokenHash, ownerId } of the response
+ * @param {Object} credentials - { user, editToken } of the caller
+ * @param {Date} now - Current time
+ * @returns {Object} Editor identity { editorId, method }
+ * @throws {Error} 401 without credentials, 403 when editing is not allowed
+ */
+function authorizeEdit(target, credentials = {}, now = new Date()) {
+  const { user, editToken } = credentials;
+  const userId = getAuthenticatedUserId(user);
+
+  if (!userId && !editToken) {
+    throw createError('Sign in or provide the edit token to edit this response', 401, 'EDIT_CREDENTIALS_REQUIRED');
+  }
+
+  const until = toDate(target.editableUntil);
+  if (!until) {
+    throw createError('Responses to this form cannot be edited', 403, 'EDITING_DISABLED');
+  }
+  if (now > until) {
+    throw createError('The edit window for this response has closed', 403, 'EDIT_WINDOW_CLOSED');
+  }
+
+  if (userId && target.ownerId && userId === target.ownerId) {
+    return { editorId: userId, method: 'submitter' };
+  }
+
+  if (holdsEditToken(target.editTokenHash, editToken)) {
+    return { editorId: userId, method: 'edit_token' };
+  }
+
+  throw createError('You are not allowed to edit this response', 403, 'EDIT_NOT_ALLOWED');
+}
+
+/**
+ * Fetch the form schema the response was originally submitted under
+ * @param {string} formId - Form ID
+ * @param {number} formVersion - Form version stored with the response
+ * @param {string} correlationId - Request correlation ID
+ * @returns {Promise<Object>} Form schema
+ * @throws {Error} 409 when the form service no longer serves that version
+ */
+async function getSubmittedSchema(formId, formVersion, correlationId) {
+  const schema = await formServiceIntegration.getFormSchema(formId, correlationId, formVersion);
+
+  if (formVersion && schema?.version && schema.version !== formVersion) {
+    throw createError(
+      `Version ${formVersion} of the form is no longer available to validate the edit`,
+      409,
+      'FORM_VERSION_UNAVAILABLE'
+    );
+  }
+
+  return schema;
+}
+
+/**
+ * Validate edited answers against a form schema
+ * @param {Object} answers - Answers keyed by question ID
+ * @param {Object} schema - Form schema
+ * @throws {Error} 400 listing the failing questions
+ */
+async function validateEditedAnswers(answers, schema) {
+  const result = await customValidators.validateResponseAnswers(answers, schema);
+  if (!result.isValid) {
+    const error = createError('Edited answers failed validation', 400, 'VALIDATION_ERROR');
+    error.details = result.errors;
+    throw error;
+  }
+}
+
+/**
+ * Build the revision record keeping the answers an edit replaces
+ * @param {Object} response - Response before the edit
+ * @param {Object} editor - Editor identity from authorizeEdit
+ * @param {string} editedAt - ISO timestamp of the edit
+ * @returns {Object} Revision record
+ */
+function buildRevision(response, editor, editedAt) {
+  return {
+    responseId: response.id,
+    formId: response.formId,
+    revision: response.revision || 1,
+    answers: response.responses,
+    editedBy: editor.editorId || null,
+    editMethod: editor.method,
+    editedAt
+  };
+}
+
+module.exports = {
+  EDIT_TOKEN_HEADER,
+  issueEditToken,
+  hashEditToken,
+  holdsEditToken,
+  editableUntil,
+  authorizeEdit,
+  getSubmittedSchema,
+  validateEditedAnswers,
+  buildRevision
+};
@@ -0,0 +1,129 @@
+/**
+ * Response mode enforcement
+ * Resolves how a form accepts submissions (anonymous, identified or one per
+ * user) and applies it to the submission path and exports
+ */
+
+const { createError } = require('../middleware/errorHandler');
+
+const RESPONSE_MODES = {
+  ANONYMOUS: 'anonymous',
+  IDENTIFIED: 'identified',
+  ONE_PER_USER: 'one_per_user'
+};
+
+/**
+ * Resolve the response policy from form settings. The form service stores
+ * settings in snake_case; camelCase is accepted for forms configured through
+ * this service. Forms without a mode fall back to require_sign_in and
+ * allow_multiple_response, matching the form service.
+ * @param {Object} settings - Form settings
+ * @returns {Object} Policy with mode, requiresLogin, oneResponsePerUser,
+ * collectMetadata and editWindowMinutes
+ */
+function resolveResponsePolicy(settings = {}) {
+  settings = settings || {};
+
+  let mode = settings.response_mode || settings.responseMode;
+  if (!Object.values(RESPONSE_MODES).includes(mode)) {
+    const requireSignIn = settings.require_sign_in ?? settings.requireSignIn ?? false;
+    const allowMultiple = settings.allow_multiple_response ?? settings.allowMultipleResponse ?? true;
+
+    if (!requireSignIn) {
+      mode = RESPONSE_MODES.ANONYMOUS;
+    } else {
+      mode = allowMultiple ? RESPONSE_MODES.IDENTIFIED : RESPONSE_MODES.ONE_PER_USER;
+    }
+  }
+
+  const requiresLogin = mode !== RESPONSE_MODES.ANONYMOUS;
+  const collectMetadata = settings.collect_metadata ?? settings.collectMetadata;
+  const editWindowMinutes = Number(settings.edit_window_minutes ?? settings.editWindowMinutes) || 0;
+
+  return {
+    mode,
+    requiresLogin,
+    oneResponsePerUser: mode === RESPONSE_MODES.ONE_PER_USER,
+    // Metadata is always kept for identified submissions
+    collectMetadata: requiresLogin || collectMetadata !== false,
+    // Respondents may edit their response this long after submitting; 0 disables editing
+    editWindowMinutes: Math.max(editWindowMinutes, 0)
+  };
+}
+
+/**
+ * Get the user ID carried by a verified JWT
+ * @param {Object} user - Decoded token (req.user)
+ * @returns {string|null} User ID
+ */
+function getAuthenticatedUserId(user) {
+  if (!user) {
+    return null;
+  }
+  return user.userId || user.id || user.sub || null;
+}
+
+/**
+ * Resolve the respondent of a submission under the policy. Identified modes
+ * require an authenticated user, whose ID is stored with the response;
+ * anonymous mode never stores a user ID.
+ * @param {Object} policy - Policy from resolveResponsePolicy
+ * @param {Object} user - Decoded token (req.user), if any
+ * @returns {Object} { submitterId, isAnonymous }
+ * @throws {Error} 401 when the form requires login and no user is present
+ */
+function resolveRespondent(policy, user) {
+  if (!policy.requiresLogin) {
+    return { submitterId: null, isAnonymous: true };
+  }
+
+  const submitterId = getAuthenticatedUserId(user);
+  if (!submitterId) {
+    throw createError('This form requires you to sign in before responding', 401, 'LOGIN_REQUIRED');
+  }
+
+  return { submitterId, isAnonymous: false };
+}
+
+/**
+ * Error returned when a one_per_user form already has a response from the user
+ * @returns {Error} 409 error
+ */
+function duplicateSubmissionError() {
+  return createError('You have already responded to this form', 409, 'DUPLICATE_SUBMISSION');
+}
+
+/**
+ * Strip the IP address and user agent from submission metadata when the
+ * policy doesn't collect them
+ * @param {Object} policy - Policy from resolveResponsePolicy
+ * @param {Object} metadata - Submission metadata
+ * @returns {Object} Metadata safe to persist
+ */
+function applyMetadataPolicy(policy, metadata = {}) {
+  if (policy.collectMetadata) {
+    return metadata;
+  }
+
+  const { ipAddress, userAgent, ...rest } = metadata;
+  return rest;
+}
+
+/**
+ * Whether identifying respondent fields must be left out of exports
+ * @param {Object} response - Stored response
+ * @returns {boolean}
+ */
+function isAnonymized(response) {
+  return !!response.isAnonymous;
+}
+
+module.exports = {
+  RESPONSE_MODES,
+  resolveResponsePolicy,
+  resolveRespondent,
+  duplicateSubmissionError,
+  applyMetadataPolicy,
+  getAuthenticatedUserId,
+  isAnonymized
+};
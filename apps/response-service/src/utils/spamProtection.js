@@ -0,0 +1,232 @@
+/**
+ * Spam protection of public submissions
+ * Forms set spam_protection to none, captcha or honeypot. Captcha forms
+ * require a captchaToken verified with the CAPTCHA provider of the service.
+ * Honeypot forms require their hidden field to be submitted empty, and the
+ * challengeToken the form service signed into the definition to be old
+ * enough that a person could have filled the form in. Blocked submissions
+ * answer 403 with the reason as error code and are counted per form.
+ *
+ * Forms the anomaly detector of the event bus throttled, for a surge of
+ * submissions, require the CAPTCHA whatever their spam protection, and
+ * accept throttling.ip_limit_per_minute submissions per IP address a minute;
+ * submissions over the limit answer 429.
+ */
+
+const crypto = require('crypto');
+const config = require('../config/enhanced');
+const logger = require('./logger');
+const metrics = require('./metrics');
+const { createError } = require('../middleware/errorHandler');
+const { VerificationClient, createCaptchaVerifier, unavailableError } = require('../integrations/captcha');
+
+const SPAM_PROTECTION = {
+  NONE: 'none',
+  CAPTCHA: 'captcha',
+  HONEYPOT: 'honeypot'
+};
+
+// Messages of the error codes blocked submissions answer with
+const BLOCK_REASONS = {
+  CAPTCHA_REQUIRED: 'A CAPTCHA token is required to submit this form',
+  CAPTCHA_FAILED: 'The CAPTCHA challenge was not passed',
+  HONEYPOT_FILLED: 'The submission was rejected as automated',
+  CHALLENGE_INVALID: 'The form challenge is missing, invalid or expired; reload the form and submit again',
+  SUBMISSION_TOO_FAST: 'The form was submitted too quickly; please try again'
+};
+
+// Submissions per IP address a minute to a throttled form, unless its
+// settings say otherwise
+const DEFAULT_THROTTLED_IP_LIMIT = 3;
+const IP_LIMIT_WINDOW_MS = 60 * 1000;
+// Entries of the IP limiter beyond which ended windows are swept
+const IP_LIMITER_SWEEP_SIZE = 10000;
+
+// Submissions to throttled forms by form and IP address, in the current window
+const throttledSubmissions = new Map();
+
+let captchaVerifier;
+
+/**
+ * The verifier of the configured CAPTCHA provider, null when none is configured
+ */
+function getCaptchaVerifier() {
+  if (captchaVerifier === undefined) {
+    const settings = config.get('spamProtection');
+    captchaVerifier = createCaptchaVerifier({
+      provider: settings.captchaProvider,
+      secret: settings.captchaSecretKey,
+      recaptchaMinScore: settings.recaptchaMinScore,
+      client: new VerificationClient({
+        timeout: settings.captchaTimeout,
+        errorCooldown: settings.captchaErrorCooldown
+      })
+    });
+  }
+  return captchaVerifier;
+}
+
+/**
+ * Resolve the spam protection of a form. The form service stores settings in
+ * snake_case; camelCase is accepted for forms configured through this service.
+ * @param {Object} settings - Form settings
+ * @returns {string} none, captcha or honeypot
+ */
+function resolveSpamProtection(settings = {}) {
+  const mode = settings?.spam_protection || settings?.spamProtection;
+  return Object.values(SPAM_PROTECTION).includes(mode) ? mode : SPAM_PROTECTION.NONE;
+}
+
+/**
+ * Whether the anomaly detector throttled the form
+ * @param {Object} form - Form, with throttled_at while throttled
+ * @returns {boolean}
+ */
+function isThrottled(form = {}) {
+  return Boolean(form?.throttled_at || form?.throttledAt);
+}
+
+/**
+ * The submissions per IP address a minute a throttled form accepts
+ * @param {Object} settings - Form settings
+ * @returns {number}
+ */
+function throttledIpLimit(settings = {}) {
+  const throttling = settings?.throttling || {};
+  const limit = Number(throttling.ip_limit_per_minute ?? throttling.ipLimitPerMinute);
+  return Number.isInteger(limit) && limit > 0 ? limit : DEFAULT_THROTTLED_IP_LIMIT;
+}
+
+/**
+ * Count a submission to a throttled form against the limit of its IP address
+ * @param {string} formId - Form submitted to
+ * @param {string} remoteIp - IP address of the respondent
+ * @param {number} limit - Submissions per minute
+ * @param {number} now - Milliseconds
+ * @returns {boolean} Whether the submission is within the limit
+ */
+function allowThrottledSubmission(formId, remoteIp, limit, now = Date.now()) {
+  if (throttledSubmissions.size > IP_LIMITER_SWEEP_SIZE) {
+    for (const [key, entry] of throttledSubmissions) {
+      if (now - entry.windowStart >= IP_LIMIT_WINDOW_MS) {
+        throttledSubmissions.delete(key);
+      }
+    }
+  }
+
+  const key = `${formId}:${remoteIp || 'unknown'}`;
+  const entry = throttledSubmissions.get(key);
+  if (!entry || now - entry.windowStart >= IP_LIMIT_WINDOW_MS) {
+    throttledSubmissions.set(key, { windowStart: now, count: 1 });
+    return true;
+  }
+  entry.count += 1;
+  return entry.count <= limit;
+}
+
+/**
+ * Check the challengeToken of a honeypot form: "<issued at>.<signature>",
+ * the issue time in Unix seconds signed with HMAC-SHA256 over
+ * "<form ID>:<issued at>"
+ * @param {string} token - Submitted challengeToken
+ * @param {string} formId - Form the token must be issued for
+ * @param {Object} options - secret, minFillSeconds, maxAgeSeconds and now (milliseconds)
+ * @returns {string|null} The reason the token is refused, null when it passes
+ */
+function checkChallengeToken(token, formId, { secret, minFillSeconds, maxAgeSeconds, now = Date.now() }) {
+  const [issued, signature] = typeof token === 'string' ? token.split('.') : [];
+  if (!/^\d+$/.test(issued || '') || !/^[0-9a-f]{64}$/.test(signature || '')) {
+    return 'CHALLENGE_INVALID';
+  }
+
+  const expected = crypto.createHmac('sha256', secret).update(`${formId}:${issued}`).digest();
+  if (!crypto.timingSafeEqual(expected, Buffer.from(signature, 'hex'))) {
+    return 'CHALLENGE_INVALID';
+  }
+
+  const elapsedSeconds = now / 1000 - Number(issued);
+  if (elapsedSeconds > maxAgeSeconds) {
+    return 'CHALLENGE_INVALID';
+  }
+  if (elapsedSeconds < minFillSeconds) {
+    return 'SUBMISSION_TOO_FAST';
+  }
+  return null;
+}
+
+/**
+ * Find why a submission fails the spam protection of its form
+ * @returns {Promise<string|null>} Error code of the reason, null when it passes
+ */
+async function findBlockReason(mode, formId, submission, remoteIp) {
+  const settings = config.get('spamProtection');
+
+  if (mode === SPAM_PROTECTION.CAPTCHA) {
+    if (!submission.captchaToken) {
+      return 'CAPTCHA_REQUIRED';
+    }
+    const verifier = getCaptchaVerifier();
+    if (!verifier) {
+      throw unavailableError(settings.captchaProvider || 'no provider');
+    }
+    return (await verifier.verify(submission.captchaToken, remoteIp)) ? null : 'CAPTCHA_FAILED';
+  }
+
+  if (mode === SPAM_PROTECTION.HONEYPOT) {
+    if (submission.honeypot) {
+      return 'HONEYPOT_FILLED';
+    }
+    return checkChallengeToken(submission.challengeToken, formId, {
+      secret: settings.challengeSecret,
+      minFillSeconds: settings.honeypotMinFillSeconds,
+      maxAgeSeconds: settings.challengeMaxAgeSeconds
+    });
+  }
+
+  return null;
+}
+
+/**
+ * Reject submissions that fail the spam protection of their form
+ * @param {Object} form - Form with id, settings and throttled_at
+ * @param {Object} submission - Request body: captchaToken, honeypot and challengeToken
+ * @param {Object} context - remoteIp and correlationId
+ * @throws {Error} 403 with the reason as code, 429 over the IP limit of a
+ *   throttled form, or 503 while the CAPTCHA provider errs
+ */
+async function checkSubmissionChallenge(form, submission, { remoteIp, correlationId } = {}) {
+  const throttled = isThrottled(form);
+  if (throttled && !allowThrottledSubmission(form.id, remoteIp, throttledIpLimit(form.settings))) {
+    metrics.spamBlockedSubmissions.inc({ form_id: form.id, reason: 'THROTTLED_RATE_LIMITED' });
+    logger.warn('Submission to a throttled form over the IP limit', { correlationId, formId: form.id, ip: remoteIp });
+    throw createError(
+      'This form is receiving unusually many submissions; please try again in a minute',
+      429,
+      'THROTTLED_RATE_LIMITED'
+    );
+  }
+
+  const mode = throttled ? SPAM_PROTECTION.CAPTCHA : resolveSpamProtection(form.settings);
+  if (mode === SPAM_PROTECTION.NONE) {
+    return;
+  }
+
+  const reason = await findBlockReason(mode, form.id, submission, remoteIp);
+  if (!reason) {
+    return;
+  }
+
+  metrics.spamBlockedSubmissions.inc({ form_id: form.id, reason });
+  logger.warn('Submission blocked by spam protection', { correlationId, formId: form.id, mode, throttled, reason, ip: remoteIp });
+  throw createError(BLOCK_REASONS[reason], 403, reason);
+}
+
+module.exports = {
+  SPAM_PROTECTION,
+  resolveSpamProtection,
+  isThrottled,
+  throttledIpLimit,
+  allowThrottledSubmission,
+  checkChallengeToken,
+  checkSubmissionChallenge
+};
@@ -0,0 +1,165 @@
+/**
+ * Submission Deduplication
+ * Identical submissions of a respondent to a form within the deduplication
+ * window, such as a double click on submit, are stored once. A submission is
+ * identified by the SHA-256 hash of its form, normalized answers and
+ * respondent: the authenticated user, else the anonymous respondent token,
+ * else the IP address. The first submission claims the hash in Redis with
+ * SET NX and a TTL of the window; later ones get the ID of the response it
+ * created.
+ *
+ * Deduplication is off unless DEDUP_ENABLED is set, and best effort: while
+ * Redis is unavailable submissions are accepted. Forms opt out with
+ * settings.deduplicate_submissions set to false.
+ */
+
+const crypto = require('crypto');
+const config = require('../config/enhanced');
+const logger = require('./logger');
+const metrics = require('./metrics');
+const { createClient, withTimeout } = require('../integrations/redis');
+
+const KEY_PREFIX = 'response-service:dedup:';
+
+let client;
+
+/**
+ * The Redis client of deduplication, created on first use
+ */
+function getClient() {
+  if (!client) {
+    client = createClient('deduplication', config.get('deduplication.redisTimeout'));
+  }
+  return client;
+}
+
+/**
+ * Whether submissions to the form are deduplicated. The form service stores
+ * settings in snake_case; camelCase is accepted for forms configured through
+ * this service.
+ * @param {Object} settings - Form settings
+ * @returns {boolean}
+ */
+function isEnabled(settings = {}) {
+  if (!config.get('deduplication.enabled')) {
+    return false;
+  }
+  const setting = settings?.deduplicate_submissions ?? settings?.deduplicateSubmissions;
+  return setting !== false;
+}
+
+/**
+ * Normalize an answer value: strings are trimmed and object keys sorted, so
+ * that incidental differences don't tell submissions apart
+ */
+function normalizeValue(value) {
+  if (typeof value === 'string') {
+    return value.trim();
+  }
+  if (Array.isArray(value)) {
+    return value.map(normalizeValue);
+  }
+  if (value && typeof value === 'object') {
+    return Object.keys(value)
+      .sort()
+      .reduce((normalized, key) => ({ ...normalized, [key]: normalizeValue(value[key]) }), {});
+  }
+  return value ?? null;
+}
+
+/**
+ * The canonical hash of a submission
+ * @param {string} formId - Form submitted to
+ * @param {Array} answers - Answers, with questionId and value
+ * @param {string} respondent - Identity of the respondent
+ * @returns {string} Hex SHA-256
+ */
+function submissionHash(formId, answers = [], respondent) {
+  const normalized = answers
+    .map(answer => [String(answer.questionId), normalizeValue(answer.value)])
+    .sort(([a], [b]) => (a < b ? -1 : a > b ? 1 : 0));
+  return crypto
+    .createHash('sha256')
+    .update(JSON.stringify([formId, respondent, normalized]))
+    .digest('hex');
+}
+
+/**
+ * The identity of the respondent of a submission
+ * @param {string|null} userId - Authenticated user ID
+ * @param {string|undefined} respondentToken - Anonymous respondent token
+ * @param {string} remoteIp - IP address
+ * @returns {string}
+ */
+function respondentIdentity(userId, respondentToken, remoteIp) {
+  if (userId) {
+    return `user:${userId}`;
+  }
+  if (respondentToken) {
+    return `token:${respondentToken}`;
+  }
+  return `ip:${remoteIp}`;
+}
+
+/**
+ * Claim a submission for a response. Fails open: when Redis is unavailable
+ * the submission is claimed.
+ * @param {string} hash - Submission hash
+ * @param {string} responseId - ID of the response the submission creates
+ * @param {Object} context - formId and correlationId, for logs and metrics
+ * @returns {Promise<string|null>} ID of the response an identical submission
+ *   created within the window, null when the submission is claimed
+ */
+async function claim(hash, responseId, { formId, correlationId } = {}) {
+  const key = KEY_PREFIX + hash;
+  const windowMs = config.get('deduplication.windowSeconds') * 1000;
+  const timeout = config.get('deduplication.redisTimeout');
+  try {
+    const redis = getClient();
+    if (await withTimeout(redis.set(key, responseId, { NX: true, PX: windowMs }), timeout)) {
+      return null;
+    }
+    const original = await withTimeout(redis.get(key), timeout);
+    if (!original) {
+      // The claim expired in between; this submission takes it
+      return null;
+    }
+    metrics.deduplicatedSubmissions.inc({ form_id: formId });
+    return original;
+  } catch (error) {
+    logger.warn('Submission deduplication unavailable, accepting the submission', {
+      correlationId,
+      formId,
+      error: error.message
+    });
+    return null;
+  }
+}
+
+/**
+ * Release the claim of a submission that failed, so that retrying it is not
+ * taken for a duplicate
+ * @param {string} hash - Submission hash
+ * @param {string} responseId - ID of the response the submission claimed for
+ */
+async function release(hash, responseId) {
+  const key = KEY_PREFIX + hash;
+  const timeout = config.get('deduplication.redisTimeout');
+  try {
+    const redis = getClient();
+    if ((await withTimeout(redis.get(key), timeout)) === responseId) {
+      await withTimeout(redis.del(key), timeout);
+    }
+  } catch (error) {
+    logger.warn('Failed to release submission deduplication claim', { error: error.message });
+  }
+}
+
+module.exports = {
+  isEnabled,
+  normalizeValue,
+  submissionHash,
+  respondentIdentity,
+  claim,
+  release
+};
@@ -35,6 +35,17 @@ const responseSchemas = {
     isAnonymous: Joi.boolean().default(false),
     isDraft: Joi.boolean().default(false),
     isComplete: Joi.boolean().default(true),
+
+    // Order the options of randomized questions were shown in, as option keys by question ID
+    presentedOrder: Joi.object().pattern(
+      Joi.string(),
+      Joi.array().items(Joi.string().max(200)).max(200)
+    ).optional(),
+
+    // Per-question timing beacons of the client, as { focusMs, revisits } by
+    // question ID. They are checked and capped by the analytics projection;
+    // a malformed payload is dropped there rather than failing the submission.
+    timings: Joi.object().optional(),
     
     // Metadata
     startTime: Joi.date().iso().optional(),
@@ -530,6 +541,78 @@ const customValidators = {
             }
           }
           break;
+
+        case 'file': {
+          // File answers carry the tokens issued by the form service's upload-url endpoint;
+          // the uploads themselves are verified against the form service on submission
+          const tokens = Array.isArray(answer) ? answer : [answer];
+          const maxCount = question.validation?.max_count || 1;
+          if (tokens.some(token => typeof token !== 'string' || token.length === 0)) {
+            errors.push({
+              questionId: question.id,
+              field: question.question || question.title,
+              message: 'Must be a file token or a list of file tokens',
+              value: answer,
+            });
+          } else if (tokens.length > maxCount) {
+            errors.push({
+              questionId: question.id,
+              field: question.question || question.title,
+              message: `At most ${maxCount} files are allowed`,
+              value: answer,
+            });
+          }
+          break;
+        }
+      }
+    }
+
+    return {
+      isValid: errors.length === 0,
+      errors,
+    };
+  },
+
+  // Validate the presented order of randomized questions: each order must list
+  // every option key of its question once, with a pinned last option kept last
+  validatePresentedOrder: (presentedOrder, formSchema) => {
+    const errors = [];
+
+    if (!presentedOrder || !formSchema || !formSchema.questions) {
+      return { isValid: true, errors: [] };
+    }
+
+    const questions = new Map(formSchema.questions.map(question => [question.id, question]));
+    for (const [questionId, order] of Object.entries(presentedOrder)) {
+      const question = questions.get(questionId);
+      if (!question || !question.display?.randomize_options) {
+        errors.push({
+          questionId,
+          field: 'presentedOrder',
+          message: 'Only questions with randomized options have a presented order',
+          value: order,
+        });
+        continue;
+      }
+
+      const keys = (question.options || []).map(opt => opt.key || opt.value || opt.text);
+      const isPermutation = order.length === keys.length &&
+        new Set(order).size === order.length &&
+        order.every(key => keys.includes(key));
+      if (!isPermutation) {
+        errors.push({
+          questionId,
+          field: 'presentedOrder',
+          message: 'Must list every option key of the question once',
+          value: order,
+        });
+      } else if (question.display.pin_last_option && order[order.length - 1] !== keys[keys.length - 1]) {
+        errors.push({
+          questionId,
+          field: 'presentedOrder',
+          message: `The pinned option ${keys[keys.length - 1]} must be presented last`,
+          value: order,
+        });
       }
     }
 
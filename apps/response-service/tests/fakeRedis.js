@@ -0,0 +1,56 @@
+/**
+ * Fake Redis
+ * An in-memory stand-in for the clients of src/integrations/redis, answering
+ * the commands the service sends. While down every command fails, as the
+ * client does while disconnected; while stalled commands are never answered.
+ */
+
+class FakeRedis {
+  constructor() {
+    this.reset();
+  }
+
+  /**
+   * Forget every key and bring the fake back up
+   */
+  reset() {
+    this.values = new Map();
+    this.ttls = new Map();
+    this.down = false;
+    this.stalled = false;
+  }
+
+  set(key, value, { NX, PX } = {}) {
+    return this._reply(() => {
+      if (NX && this.values.has(key)) {
+        return null;
+      }
+      this.values.set(key, value);
+      this.ttls.set(key, PX);
+      return 'OK';
+    });
+  }
+
+  get(key) {
+    return this._reply(() => this.values.get(key) ?? null);
+  }
+
+  del(key) {
+    return this._reply(() => {
+      this.ttls.delete(key);
+      return Number(this.values.delete(key));
+    });
+  }
+
+  _reply(command) {
+    if (this.down) {
+      return Promise.reject(new Error('The client is closed'));
+    }
+    if (this.stalled) {
+      return new Promise(() => {});
+    }
+    return Promise.resolve(command());
+  }
+}
+
+module.exports = new FakeRedis();
@@ -0,0 +1,98 @@
+/**
+ * Response Controller Deduplication Tests
+ */
+
+process.env.DEDUP_ENABLED = 'true';
+
+jest.mock('../src/integrations/redis', () => ({
+  ...jest.requireActual('../src/integrations/redis'),
+  createClient: () => require('./fakeRedis')
+}));
+jest.mock('../src/integrations/formService');
+jest.mock('../src/utils/spamProtection');
+
+const responseController = require('../src/controllers/responseController');
+const formServiceIntegration = require('../src/integrations/formService');
+const fakeRedis = require('./fakeRedis');
+
+const FORM_ID = 'f123e4567-e89b-12d3-a456-426614174000';
+
+/**
+ * A submission to the seeded anonymous form from one IP address
+ */
+function submission(value = 'Great service') {
+  const headers = { 'x-correlation-id': 'test-correlation' };
+  return {
+    headers,
+    ip: '10.0.0.1',
+    body: { formId: FORM_ID, responses: [{ questionId: 'q1', value }] },
+    get: name => headers[name.toLowerCase()]
+  };
+}
+
+/**
+ * Submit req and resolve to the status and body answered
+ */
+async function submit(req) {
+  const res = {
+    status(code) {
+      this.statusCode = code;
+      return this;
+    },
+    json(body) {
+      this.body = body;
+      return this;
+    }
+  };
+  await responseController.createResponse(req, res);
+  return res;
+}
+
+beforeEach(() => {
+  fakeRedis.reset();
+  responseController.store.responses.clear();
+  formServiceIntegration.admitResponse.mockResolvedValue(undefined);
+  formServiceIntegration.consumeDraft.mockResolvedValue(undefined);
+});
+
+afterEach(() => {
+  jest.clearAllMocks();
+});
+
+describe('createResponse deduplication', () => {
+  it('answers an identical submission with the original response', async () => {
+    const first = await submit(submission());
+    expect(first.statusCode).toBe(201);
+
+    const second = await submit(submission('  Great service '));
+    expect(second.statusCode).toBe(200);
+    expect(second.body.data).toMatchObject({ id: first.body.data.id, isDuplicate: true });
+
+    expect(responseController.store.responses.size).toBe(1);
+    expect(formServiceIntegration.admitResponse).toHaveBeenCalledTimes(1);
+  });
+
+  it('stores different answers', async () => {
+    await submit(submission('Great service'));
+    const other = await submit(submission('Slow delivery'));
+    expect(other.statusCode).toBe(201);
+    expect(responseController.store.responses.size).toBe(2);
+  });
+
+  it('releases the claim of a submission that failed', async () => {
+    formServiceIntegration.admitResponse.mockRejectedValueOnce(new Error('quota service unavailable'));
+    await expect(submit(submission())).rejects.toThrow('quota service unavailable');
+    expect(fakeRedis.values.size).toBe(0);
+
+    const retry = await submit(submission());
+    expect(retry.statusCode).toBe(201);
+    expect(responseController.store.responses.size).toBe(1);
+  });
+
+  it('accepts every submission while Redis is unreachable', async () => {
+    fakeRedis.down = true;
+    expect((await submit(submission())).statusCode).toBe(201);
+    expect((await submit(submission())).statusCode).toBe(201);
+    expect(responseController.store.responses.size).toBe(2);
+  });
+});
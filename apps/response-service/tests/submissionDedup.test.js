@@ -0,0 +1,118 @@
+/**
+ * Submission Deduplication Tests
+ */
+
+jest.mock('../src/integrations/redis', () => ({
+  ...jest.requireActual('../src/integrations/redis'),
+  createClient: () => require('./fakeRedis')
+}));
+
+const config = require('../src/config/enhanced');
+const submissionDedup = require('../src/utils/submissionDedup');
+const fakeRedis = require('./fakeRedis');
+
+const FORM_ID = 'f123e4567-e89b-12d3-a456-426614174000';
+const KEY_PREFIX = 'response-service:dedup:';
+
+/**
+ * Override configuration values, leaving the others as configured
+ */
+function withConfig(overrides) {
+  const get = config.get.bind(config);
+  jest.spyOn(config, 'get').mockImplementation(path => (path in overrides ? overrides[path] : get(path)));
+}
+
+afterEach(() => {
+  jest.restoreAllMocks();
+  fakeRedis.reset();
+});
+
+describe('submissionHash', () => {
+  const answers = [
+    { questionId: 'q1', value: 'Ada Lovelace' },
+    { questionId: 'q2', value: { city: 'London', country: 'UK' } },
+    { questionId: 'q3', value: ['a', 'b'] }
+  ];
+  const hash = submissionDedup.submissionHash(FORM_ID, answers, 'user:u1');
+
+  it('ignores whitespace, answer order and key order', () => {
+    const reordered = [
+      { questionId: 'q3', value: ['a ', ' b'] },
+      { questionId: 'q2', value: { country: 'UK', city: ' London' } },
+      { questionId: 'q1', value: '  Ada Lovelace\n' }
+    ];
+    expect(submissionDedup.submissionHash(FORM_ID, reordered, 'user:u1')).toBe(hash);
+  });
+
+  it('tells apart forms, respondents and answers', () => {
+    expect(submissionDedup.submissionHash('another-form', answers, 'user:u1')).not.toBe(hash);
+    expect(submissionDedup.submissionHash(FORM_ID, answers, 'user:u2')).not.toBe(hash);
+    expect(submissionDedup.submissionHash(FORM_ID, [...answers.slice(1), { questionId: 'q1', value: 'Ada' }], 'user:u1')).not.toBe(hash);
+    // Option order is an answer, not an incidental difference
+    expect(submissionDedup.submissionHash(FORM_ID, [answers[0], answers[1], { questionId: 'q3', value: ['b', 'a'] }], 'user:u1')).not.toBe(hash);
+  });
+
+  it('normalizes missing values to null', () => {
+    expect(submissionDedup.normalizeValue(undefined)).toBeNull();
+    expect(submissionDedup.normalizeValue({ b: undefined, a: 1 })).toEqual({ a: 1, b: null });
+  });
+});
+
+describe('respondentIdentity', () => {
+  it('prefers the user, then the respondent token, then the IP address', () => {
+    expect(submissionDedup.respondentIdentity('u1', 'token-1', '10.0.0.1')).toBe('user:u1');
+    expect(submissionDedup.respondentIdentity(null, 'token-1', '10.0.0.1')).toBe('token:token-1');
+    expect(submissionDedup.respondentIdentity(null, undefined, '10.0.0.1')).toBe('ip:10.0.0.1');
+  });
+});
+
+describe('isEnabled', () => {
+  it('is off unless DEDUP_ENABLED is set', () => {
+    expect(config.get('deduplication.enabled')).toBe(false);
+    expect(submissionDedup.isEnabled({})).toBe(false);
+  });
+
+  it('lets forms opt out once enabled', () => {
+    withConfig({ 'deduplication.enabled': true });
+    expect(submissionDedup.isEnabled({})).toBe(true);
+    expect(submissionDedup.isEnabled({ deduplicate_submissions: false })).toBe(false);
+    expect(submissionDedup.isEnabled({ deduplicateSubmissions: false })).toBe(false);
+  });
+});
+
+describe('claim and release', () => {
+  const hash = submissionDedup.submissionHash(FORM_ID, [{ questionId: 'q1', value: 'yes' }], 'ip:10.0.0.1');
+
+  it('claims a submission once per window with SET NX', async () => {
+    await expect(submissionDedup.claim(hash, 'r1', { formId: FORM_ID })).resolves.toBeNull();
+    expect(fakeRedis.values.get(KEY_PREFIX + hash)).toBe('r1');
+    expect(fakeRedis.ttls.get(KEY_PREFIX + hash)).toBe(config.get('deduplication.windowSeconds') * 1000);
+
+    await expect(submissionDedup.claim(hash, 'r2', { formId: FORM_ID })).resolves.toBe('r1');
+    expect(fakeRedis.values.get(KEY_PREFIX + hash)).toBe('r1');
+  });
+
+  it('releases only its own claim', async () => {
+    await submissionDedup.claim(hash, 'r1', { formId: FORM_ID });
+
+    await submissionDedup.release(hash, 'r2');
+    expect(fakeRedis.values.get(KEY_PREFIX + hash)).toBe('r1');
+
+    await submissionDedup.release(hash, 'r1');
+    expect(fakeRedis.values.has(KEY_PREFIX + hash)).toBe(false);
+    await expect(submissionDedup.claim(hash, 'r3', { formId: FORM_ID })).resolves.toBeNull();
+  });
+
+  it('accepts submissions while Redis is unreachable', async () => {
+    fakeRedis.down = true;
+    await expect(submissionDedup.claim(hash, 'r1', { formId: FORM_ID })).resolves.toBeNull();
+    await expect(submissionDedup.claim(hash, 'r2', { formId: FORM_ID })).resolves.toBeNull();
+    await expect(submissionDedup.release(hash, 'r1')).resolves.toBeUndefined();
+  });
+
+  it('accepts submissions when Redis does not answer in time', async () => {
+    withConfig({ 'deduplication.redisTimeout': 20 });
+    fakeRedis.stalled = true;
+    await expect(submissionDedup.claim(hash, 'r1', { formId: FORM_ID })).resolves.toBeNull();
+  });
+});
@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// AnalyticsService reads form analytics and runs exports through the
+// Analytics Service
+type AnalyticsService struct {
+	client *Client
+}
+
+// SummaryOptions limits a summary to a date range
+type SummaryOptions struct {
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+// AnalyticsSummary summarises the responses of a form
+type AnalyticsSummary struct {
+	FormID                string                   `json:"form_id"`
+	Title                 string                   `json:"title"`
+	TotalResponses        int                      `json:"total_responses"`
+	CompletedResponses    int                      `json:"completed_responses"`
+	PartialResponses      int                      `json:"partial_responses"`
+	AverageCompletionTime *float64                 `json:"average_completion_time"`
+	CompletionRate        float64                  `json:"completion_rate"`
+	FirstResponseDate     *time.Time               `json:"first_response_date"`
+	LastResponseDate      *time.Time               `json:"last_response_date"`
+	UniqueRespondents     int                      `json:"unique_respondents"`
+	ResponseRateTrend     []map[string]interface{} `json:"response_rate_trend"`
+}
+
+// ExportRequest configures an export of the responses of a form
+type ExportRequest struct {
+	// Format is csv, xlsx, json or pdf
+	Format          string            `json:"format"`
+	IncludeMetadata bool              `json:"include_metadata"`
+	DateRange       map[string]string `json:"date_range,omitempty"`
+	Filters         map[string]string `json:"filters,omitempty"`
+	Columns         []string          `json:"columns,omitempty"`
+}
+
+// Export statuses
+const (
+	ExportPending    = "pending"
+	ExportProcessing = "processing"
+	ExportCompleted  = "completed"
+	ExportFailed     = "failed"
+	ExportExpired    = "expired"
+)
+
+// ExportJob is the state of an export
+type ExportJob struct {
+	ExportID            string     `json:"export_id"`
+	Status              string     `json:"status"`
+	Progress            int        `json:"progress,omitempty"`
+	EstimatedCompletion string     `json:"estimated_completion,omitempty"`
+	RecordsProcessed    int        `json:"records_processed,omitempty"`
+	Filename            string     `json:"filename,omitempty"`
+	SizeBytes           int64      `json:"size_bytes,omitempty"`
+	DownloadURL         string     `json:"download_url,omitempty"`
+	ExpiresAt           *time.Time `json:"expires_at,omitempty"`
+}
+
+// Done reports whether the export stopped, successfully or not
+func (j *ExportJob) Done() bool {
+	return j.Status == ExportCompleted || j.Status == ExportFailed || j.Status == ExportExpired
+}
+
+// FormSummary returns the analytics summary of a form; opts may be nil
+func (s *AnalyticsService) FormSummary(ctx context.Context, formID string, opts *SummaryOptions) (*AnalyticsSummary, error) {
+	if formID == "" {
+		return nil, errMissingID
+	}
+	q := url.Values{}
+	if opts != nil && !opts.StartDate.IsZero() {
+		q.Set("start_date", opts.StartDate.Format(time.RFC3339))
+	}
+	if opts != nil && !opts.EndDate.IsZero() {
+		q.Set("end_date", opts.EndDate.Format(time.RFC3339))
+	}
+
+	var summary AnalyticsSummary
+	if _, err := s.client.do(ctx, http.MethodGet, pathf("/analytics/%s/summary", formID), q, nil, &summary); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// CreateExport starts an export of the responses of a form. Poll ExportStatus
+// until the job is done.
+func (s *AnalyticsService) CreateExport(ctx context.Context, formID string, req *ExportRequest) (*ExportJob, error) {
+	if formID == "" {
+		return nil, errMissingID
+	}
+	if req == nil {
+		req = &ExportRequest{Format: "csv", IncludeMetadata: true}
+	}
+	var job ExportJob
+	if _, err := s.client.do(ctx, http.MethodPost, pathf("/reports/%s/export", formID), nil, req, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ExportStatus returns the state of an export
+func (s *AnalyticsService) ExportStatus(ctx context.Context, formID, exportID string) (*ExportJob, error) {
+	if formID == "" || exportID == "" {
+		return nil, errMissingID
+	}
+	var job ExportJob
+	path := pathf("/reports/%s/export/%s/status", formID, exportID)
+	if _, err := s.client.do(ctx, http.MethodGet, path, nil, nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
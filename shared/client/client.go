@@ -0,0 +1,207 @@
+// Package client is the Go SDK for the X-Form public API served by the API
+// Gateway. It covers forms, responses, analytics and event publishing:
+//
+//	c, err := client.NewClient(client.Config{
+//		BaseURL: "https://gateway.example.com",
+//		Token:   jwt,
+//	})
+//	form, err := c.Forms.Get(ctx, formID)
+//
+// Every call unwraps the standard response envelope and returns failures as
+// *APIError, which matches the sentinel errors of this package with
+// errors.Is. Idempotent calls are retried when the gateway answers 502 or 503.
+//
+// The form types are generated from the Form Service Swagger spec:
+//
+//	go generate ./...
+package client
+
+//go:generate go run ./internal/gen -spec ../../apps/form-service/docs/swagger.json -out forms_gen.go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// APIKeyHeader carries API keys issued by the gateway
+const APIKeyHeader = "X-API-Key"
+
+// Config configures a Client
+type Config struct {
+	// BaseURL of the API Gateway, e.g. https://gateway.example.com
+	BaseURL string
+
+	// Token is a JWT sent as a bearer token
+	Token string
+	// APIKey is sent in the X-API-Key header. Token and APIKey may be combined.
+	APIKey string
+
+	// HTTPClient performs the requests; a client with a 30s timeout when nil
+	HTTPClient *http.Client
+
+	// MaxRetries of idempotent calls answered with 502 or 503; 3 when zero,
+	// negative disables retries
+	MaxRetries int
+	// RetryBackoff before the first retry, doubled for every further one;
+	// 200ms when zero
+	RetryBackoff time.Duration
+
+	// UserAgent identifies the caller; defaults to xform-go-client
+	UserAgent string
+}
+
+// Client calls the X-Form public API
+type Client struct {
+	Forms     *FormsService
+	Responses *ResponsesService
+	Analytics *AnalyticsService
+	Events    *EventsService
+
+	baseURL      *url.URL
+	http         *http.Client
+	token        string
+	apiKey       string
+	userAgent    string
+	maxRetries   int
+	retryBackoff time.Duration
+}
+
+// NewClient creates a client for the gateway at cfg.BaseURL
+func NewClient(cfg Config) (*Client, error) {
+	baseURL, err := url.Parse(strings.TrimSuffix(cfg.BaseURL, "/"))
+	if err != nil || baseURL.Scheme == "" || baseURL.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q", cfg.BaseURL)
+	}
+
+	c := &Client{
+		baseURL:      baseURL,
+		http:         cfg.HTTPClient,
+		token:        cfg.Token,
+		apiKey:       cfg.APIKey,
+		userAgent:    cfg.UserAgent,
+		maxRetries:   cfg.MaxRetries,
+		retryBackoff: cfg.RetryBackoff,
+	}
+	if c.http == nil {
+		c.http = &http.Client{Timeout: 30 * time.Second}
+	}
+	if c.userAgent == "" {
+		c.userAgent = "xform-go-client"
+	}
+	if c.maxRetries == 0 {
+		c.maxRetries = 3
+	}
+	if c.retryBackoff <= 0 {
+		c.retryBackoff = 200 * time.Millisecond
+	}
+
+	c.Forms = &FormsService{client: c}
+	c.Responses = &ResponsesService{client: c}
+	c.Analytics = &AnalyticsService{client: c}
+	c.Events = &EventsService{client: c}
+	return c, nil
+}
+
+// do sends a request and decodes the data of the response envelope into out,
+// which may be nil. It returns the envelope for callers needing pagination.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) (*envelope, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	endpoint := c.baseURL.JoinPath(path)
+	endpoint.RawQuery = query.Encode()
+
+	backoff := c.retryBackoff
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, method, endpoint.String(), payload)
+		if err != nil {
+			return nil, err
+		}
+
+		if retryable(method, resp.StatusCode) && attempt < c.maxRetries {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+			continue
+		}
+
+		return decodeResponse(resp, out)
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, endpoint string, payload []byte) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if c.apiKey != "" {
+		req.Header.Set(APIKeyHeader, c.apiKey)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		// Report cancellation as the context error rather than a url.Error
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("%s %s failed: %w", method, endpoint, err)
+	}
+	return resp, nil
+}
+
+// retryable reports whether a response may be retried. Only idempotent
+// methods are, so a submission is never recorded twice.
+func retryable(method string, status int) bool {
+	if status != http.StatusBadGateway && status != http.StatusServiceUnavailable {
+		return false
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// pathf builds a request path, escaping each argument as a path segment
+func pathf(format string, args ...string) string {
+	escaped := make([]interface{}, len(args))
+	for i, arg := range args {
+		escaped[i] = url.PathEscape(arg)
+	}
+	return fmt.Sprintf(format, escaped...)
+}
+
+// errMissingID is returned before any request when a resource ID is empty
+var errMissingID = errors.New("client: resource ID is required")
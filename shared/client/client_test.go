@@ -0,0 +1,275 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stub serves handler and returns a client for it with fast retries
+func stub(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	c, err := NewClient(Config{
+		BaseURL:      server.URL + "/",
+		Token:        "jwt",
+		APIKey:       "key",
+		RetryBackoff: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func TestNewClientRejectsInvalidBaseURL(t *testing.T) {
+	for _, baseURL := range []string{"", "gateway", "://gateway"} {
+		if _, err := NewClient(Config{BaseURL: baseURL}); err == nil {
+			t.Errorf("NewClient(%q) succeeded", baseURL)
+		}
+	}
+}
+
+func TestRequestsCarryCredentials(t *testing.T) {
+	c := stub(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer jwt" {
+			t.Errorf("Authorization = %q", got)
+		}
+		if got := r.Header.Get(APIKeyHeader); got != "key" {
+			t.Errorf("%s = %q", APIKeyHeader, got)
+		}
+		if r.URL.EscapedPath() != "/forms/f%201" {
+			t.Errorf("path = %q", r.URL.EscapedPath())
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"success": true, "data": map[string]string{"id": "f 1"}})
+	})
+
+	if _, err := c.Forms.Get(context.Background(), "f 1"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEnvelopeDataIsUnwrapped(t *testing.T) {
+	c := stub(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusCreated, map[string]interface{}{
+			"success": true,
+			"message": "Response submitted successfully",
+			"data": map[string]interface{}{
+				"id":          "r1",
+				"formId":      "f1",
+				"status":      "completed",
+				"revision":    1,
+				"editToken":   "token",
+				"submittedAt": "2026-10-01T12:00:00Z",
+			},
+		})
+	})
+
+	response, err := c.Responses.Submit(context.Background(), &SubmitResponseRequest{FormID: "f1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.ID != "r1" || response.EditToken != "token" || response.Revision != 1 {
+		t.Errorf("response = %+v", response)
+	}
+	if !response.SubmittedAt.Equal(time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("SubmittedAt = %v", response.SubmittedAt)
+	}
+}
+
+func TestFormNestedUnderFormIsUnwrapped(t *testing.T) {
+	c := stub(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusCreated, map[string]interface{}{
+			"message": "Form created successfully",
+			"form":    map[string]interface{}{"id": "f1", "title": "Survey"},
+		})
+	})
+
+	form, err := c.Forms.Create(context.Background(), &CreateFormRequest{Title: "Survey"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if form.ID != "f1" || form.Title != "Survey" {
+		t.Errorf("form = %+v", form)
+	}
+}
+
+func TestListFormsReadsPagination(t *testing.T) {
+	c := stub(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("pageSize"); got != "5" {
+			t.Errorf("pageSize = %q", got)
+		}
+		if got := r.URL.Query().Get("status"); got != "published" {
+			t.Errorf("status = %q", got)
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"success":    true,
+			"data":       []map[string]string{{"id": "f1"}, {"id": "f2"}},
+			"pagination": map[string]interface{}{"page": 2, "pageSize": 5, "total": 7, "hasPrev": true},
+		})
+	})
+
+	list, err := c.Forms.List(context.Background(), &ListFormsOptions{Page: 2, PageSize: 5, Status: "published"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Forms) != 2 || list.Pagination.Total != 7 || !list.Pagination.HasPrev {
+		t.Errorf("list = %+v", list)
+	}
+}
+
+func TestStructuredErrorBecomesAPIError(t *testing.T) {
+	c := stub(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusForbidden, map[string]interface{}{
+			"success": false,
+			"error": map[string]interface{}{
+				"code":          "EDIT_WINDOW_CLOSED",
+				"message":       "The edit window for this response has closed",
+				"details":       map[string]string{"field": "editableUntil"},
+				"correlationId": "corr-1",
+			},
+		})
+	})
+
+	_, err := c.Responses.Get(context.Background(), "r1")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v, want *APIError", err)
+	}
+	if apiErr.Code != "EDIT_WINDOW_CLOSED" || apiErr.CorrelationID != "corr-1" || len(apiErr.Details) == 0 {
+		t.Errorf("apiErr = %+v", apiErr)
+	}
+	if !errors.Is(err, ErrForbidden) || errors.Is(err, ErrNotFound) {
+		t.Errorf("errors.Is matched the wrong sentinel for %v", err)
+	}
+}
+
+func TestUnstructuredErrorsGetCodeFromStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    interface{}
+		code    string
+		message string
+		target  error
+	}{
+		{"form service", http.StatusNotFound, map[string]string{"error": "form not found"}, "NOT_FOUND", "form not found", ErrNotFound},
+		{"analytics", http.StatusBadRequest, map[string]string{"detail": "Unsupported export format"}, "VALIDATION_ERROR", "Unsupported export format", ErrBadRequest},
+		{"event bus", http.StatusInternalServerError, map[string]interface{}{"success": false, "message": "Failed to publish event", "error": "broker down"}, "INTERNAL_SERVER_ERROR", "Failed to publish event: broker down", nil},
+		{"plain text", http.StatusConflict, "already exists", "CONFLICT", `"already exists"`, ErrConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := stub(t, func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, tt.status, tt.body)
+			})
+
+			_, err := c.Events.Publish(context.Background(), &Event{EventType: "form.created", Source: "test"})
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("err = %v, want *APIError", err)
+			}
+			if apiErr.Code != tt.code || apiErr.Message != tt.message {
+				t.Errorf("apiErr = %+v", apiErr)
+			}
+			if tt.target != nil && !errors.Is(err, tt.target) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tt.target)
+			}
+		})
+	}
+}
+
+func TestIdempotentCallsAreRetried(t *testing.T) {
+	var calls int32
+	c := stub(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"success": true, "data": map[string]string{"export_id": "e1", "status": "completed"}})
+	})
+
+	job, err := c.Analytics.ExportStatus(context.Background(), "f1", "e1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !job.Done() || calls != 3 {
+		t.Errorf("job = %+v after %d calls", job, calls)
+	}
+}
+
+func TestRetriesGiveUpWithUnavailable(t *testing.T) {
+	var calls int32
+	c := stub(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	_, err := c.Forms.Get(context.Background(), "f1")
+	if !errors.Is(err, ErrUnavailable) {
+		t.Errorf("err = %v, want ErrUnavailable", err)
+	}
+	if calls != 4 {
+		t.Errorf("calls = %d, want the first attempt and 3 retries", calls)
+	}
+}
+
+func TestSubmissionsAreNotRetried(t *testing.T) {
+	var calls int32
+	c := stub(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	if _, err := c.Responses.Submit(context.Background(), &SubmitResponseRequest{FormID: "f1"}); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("err = %v, want ErrUnavailable", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestCancellationStopsRetries(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	c, err := NewClient(Config{BaseURL: server.URL, RetryBackoff: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Forms.Get(ctx, "f1"); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestMissingIDFailsBeforeRequest(t *testing.T) {
+	c := stub(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL.Path)
+	})
+
+	if _, err := c.Forms.Get(context.Background(), ""); err != errMissingID {
+		t.Errorf("err = %v, want errMissingID", err)
+	}
+	if err := c.Forms.Delete(context.Background(), ""); err != errMissingID {
+		t.Errorf("err = %v, want errMissingID", err)
+	}
+}
@@ -0,0 +1,196 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Sentinel errors matched by *APIError with errors.Is
+var (
+	ErrBadRequest   = &APIError{StatusCode: http.StatusBadRequest}
+	ErrUnauthorized = &APIError{StatusCode: http.StatusUnauthorized}
+	ErrForbidden    = &APIError{StatusCode: http.StatusForbidden}
+	ErrNotFound     = &APIError{StatusCode: http.StatusNotFound}
+	ErrConflict     = &APIError{StatusCode: http.StatusConflict}
+	ErrRateLimited  = &APIError{StatusCode: http.StatusTooManyRequests}
+	ErrUnavailable  = &APIError{StatusCode: http.StatusServiceUnavailable}
+)
+
+// APIError is a failed API call, decoded from the error of the envelope
+type APIError struct {
+	// StatusCode is the HTTP status of the response
+	StatusCode int
+	// Code is the machine readable error code, e.g. VALIDATION_ERROR. Services
+	// that don't send one get a code derived from the status.
+	Code    string
+	Message string
+	// Details holds service specific details, such as failing fields
+	Details       json.RawMessage
+	CorrelationID string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("xform: %d %s", e.StatusCode, e.Code)
+	}
+	return fmt.Sprintf("xform: %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// Is matches the sentinel errors by status; ErrUnavailable also matches 502
+func (e *APIError) Is(target error) bool {
+	t, ok := target.(*APIError)
+	if !ok || t.Code != "" || t.Message != "" {
+		return false
+	}
+	if t.StatusCode == http.StatusServiceUnavailable && e.StatusCode == http.StatusBadGateway {
+		return true
+	}
+	return t.StatusCode == e.StatusCode
+}
+
+// envelope is the standard response of the services:
+//
+//	{"success": true, "data": {...}, "message": "...", "correlationId": "..."}
+//	{"success": false, "error": {"code": "...", "message": "...", "details": ...}}
+//
+// Some services send the error as a string, or answer FastAPI style with a
+// detail field, and some don't wrap successful responses at all.
+type envelope struct {
+	Success       *bool           `json:"success"`
+	Message       string          `json:"message"`
+	Data          json.RawMessage `json:"data"`
+	Error         json.RawMessage `json:"error"`
+	Detail        json.RawMessage `json:"detail"`
+	Pagination    json.RawMessage `json:"pagination"`
+	CorrelationID string          `json:"correlationId"`
+	RequestID     string          `json:"request_id"`
+}
+
+// errorBody is the structured error of the envelope
+type errorBody struct {
+	Code          string          `json:"code"`
+	Message       string          `json:"message"`
+	Details       json.RawMessage `json:"details"`
+	CorrelationID string          `json:"correlationId"`
+	RequestID     string          `json:"requestId"`
+}
+
+// decodeResponse closes resp and decodes its data into out, or returns the
+// *APIError of a failed call
+func decodeResponse(resp *http.Response, out interface{}) (*envelope, error) {
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var env envelope
+	wrapped := false
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '{' {
+		if err := json.Unmarshal(trimmed, &env); err == nil {
+			wrapped = env.Success != nil
+		}
+	}
+
+	if resp.StatusCode >= 400 || (wrapped && !*env.Success) {
+		return nil, newAPIError(resp, &env, body)
+	}
+
+	if out == nil || len(bytes.TrimSpace(body)) == 0 {
+		return &env, nil
+	}
+	data := body
+	if wrapped {
+		data = env.Data
+	}
+	if len(data) == 0 || string(data) == "null" {
+		return &env, nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &env, nil
+}
+
+func newAPIError(resp *http.Response, env *envelope, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode:    resp.StatusCode,
+		Message:       env.Message,
+		CorrelationID: env.CorrelationID,
+	}
+	if apiErr.CorrelationID == "" {
+		apiErr.CorrelationID = env.RequestID
+	}
+
+	var structured errorBody
+	var text string
+	switch {
+	case isNull(env.Error) && isNull(env.Detail) && env.Success == nil && env.Message == "":
+		apiErr.Message = string(bytes.TrimSpace(body))
+	case isNull(env.Error) && isNull(env.Detail):
+		// The envelope message is all there is
+	case json.Unmarshal(env.Error, &structured) == nil && (structured.Code != "" || structured.Message != ""):
+		apiErr.Code = structured.Code
+		if structured.Message != "" {
+			apiErr.Message = structured.Message
+		}
+		apiErr.Details = structured.Details
+		if structured.CorrelationID != "" {
+			apiErr.CorrelationID = structured.CorrelationID
+		} else if structured.RequestID != "" && apiErr.CorrelationID == "" {
+			apiErr.CorrelationID = structured.RequestID
+		}
+	case json.Unmarshal(env.Error, &text) == nil:
+		if apiErr.Message == "" {
+			apiErr.Message = text
+		} else {
+			apiErr.Message += ": " + text
+		}
+	case json.Unmarshal(env.Detail, &text) == nil:
+		apiErr.Message = text
+	case !isNull(env.Error):
+		apiErr.Details = env.Error
+	default:
+		apiErr.Details = env.Detail
+	}
+
+	if apiErr.CorrelationID == "" {
+		apiErr.CorrelationID = resp.Header.Get("X-Correlation-ID")
+	}
+	if apiErr.Code == "" {
+		apiErr.Code = codeForStatus(resp.StatusCode)
+	}
+	return apiErr
+}
+
+func isNull(raw json.RawMessage) bool {
+	return len(raw) == 0 || string(raw) == "null"
+}
+
+// codeForStatus names the error of services that only send a status
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return "VALIDATION_ERROR"
+	case http.StatusUnauthorized:
+		return "UNAUTHORIZED"
+	case http.StatusForbidden:
+		return "FORBIDDEN"
+	case http.StatusNotFound:
+		return "NOT_FOUND"
+	case http.StatusConflict:
+		return "CONFLICT"
+	case http.StatusRequestEntityTooLarge:
+		return "PAYLOAD_TOO_LARGE"
+	case http.StatusTooManyRequests:
+		return "RATE_LIMITED"
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return "SERVICE_UNAVAILABLE"
+	default:
+		return "INTERNAL_SERVER_ERROR"
+	}
+}
@@ -0,0 +1,41 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// EventsService publishes events through the Event Bus Service
+type EventsService struct {
+	client *Client
+}
+
+// Event is an event to publish
+type Event struct {
+	EventType string                 `json:"event_type"`
+	Source    string                 `json:"source"`
+	Subject   string                 `json:"subject,omitempty"`
+	Data      map[string]interface{} `json:"data"`
+	Headers   map[string]string      `json:"headers,omitempty"`
+	// Topic defaults to app.<event_type>
+	Topic        string `json:"topic,omitempty"`
+	Key          string `json:"key,omitempty"`
+	PartitionKey string `json:"partition_key,omitempty"`
+}
+
+// PublishResult identifies a published event
+type PublishResult struct {
+	EventID string `json:"event_id"`
+	Topic   string `json:"topic"`
+	Status  string `json:"status"`
+}
+
+// Publish publishes an event. Publishing is not retried, so consumers never
+// see a retry as a second event.
+func (s *EventsService) Publish(ctx context.Context, event *Event) (*PublishResult, error) {
+	var result PublishResult
+	if _, err := s.client.do(ctx, http.MethodPost, "/events", nil, event, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
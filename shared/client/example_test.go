@@ -0,0 +1,80 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/Mir00r/X-Form-Backend/shared/client"
+)
+
+func ExampleNewClient() {
+	c, err := client.NewClient(client.Config{
+		BaseURL: "https://gateway.example.com",
+		APIKey:  os.Getenv("XFORM_API_KEY"),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	form, err := c.Forms.Get(context.Background(), "550e8400-e29b-41d4-a716-446655440000")
+	switch {
+	case errors.Is(err, client.ErrNotFound):
+		fmt.Println("no such form")
+	case err != nil:
+		log.Fatal(err)
+	default:
+		fmt.Println(form.Title)
+	}
+}
+
+func ExampleResponsesService_Submit() {
+	c, err := client.NewClient(client.Config{BaseURL: "https://gateway.example.com"})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	response, err := c.Responses.Submit(context.Background(), &client.SubmitResponseRequest{
+		FormID: "form_user_feedback_2023",
+		Responses: []client.Answer{
+			{QuestionID: "q_satisfaction", QuestionType: "rating", Value: 5},
+		},
+	})
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) && apiErr.Code == "DUPLICATE_SUBMISSION" {
+		fmt.Println("already responded")
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Keep the edit token to edit the response within the form's edit window
+	fmt.Println(response.ID, response.EditToken)
+}
+
+func ExampleAnalyticsService_CreateExport() {
+	c, err := client.NewClient(client.Config{
+		BaseURL: "https://gateway.example.com",
+		Token:   os.Getenv("XFORM_TOKEN"),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	formID := "550e8400-e29b-41d4-a716-446655440000"
+	job, err := c.Analytics.CreateExport(ctx, formID, &client.ExportRequest{Format: "xlsx"})
+	for err == nil && !job.Done() {
+		time.Sleep(5 * time.Second)
+		job, err = c.Analytics.ExportStatus(ctx, formID, job.ExportID)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(job.Status, job.DownloadURL)
+}
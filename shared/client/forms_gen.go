@@ -0,0 +1,178 @@
+// Code generated by internal/gen from apps/form-service/docs/swagger.json. DO NOT EDIT.
+
+package client
+
+import "time"
+
+// Condition is generated from dto.ConditionDTO
+type Condition struct {
+	Operator   string `json:"operator"`
+	QuestionID string `json:"questionId"`
+	Value      string `json:"value,omitempty"`
+}
+
+// ConditionalLogic is generated from dto.ConditionalLogicDTO
+type ConditionalLogic struct {
+	HideIf []Condition `json:"hideIf,omitempty"`
+	Logic  string      `json:"logic,omitempty"`
+	ShowIf []Condition `json:"showIf,omitempty"`
+}
+
+// CreateFormRequest is generated from dto.CreateFormRequestDTO
+type CreateFormRequest struct {
+	AllowMultiple *bool                   `json:"allowMultiple,omitempty"`
+	Category      *string                 `json:"category,omitempty"`
+	Description   *string                 `json:"description,omitempty"`
+	ExpiresAt     *time.Time              `json:"expiresAt,omitempty"`
+	IsAnonymous   *bool                   `json:"isAnonymous,omitempty"`
+	IsPublic      *bool                   `json:"isPublic,omitempty"`
+	Questions     []CreateQuestionRequest `json:"questions"`
+	Settings      *FormSettings           `json:"settings,omitempty"`
+	Tags          []string                `json:"tags,omitempty"`
+	Title         string                  `json:"title"`
+}
+
+// CreateQuestionRequest is generated from dto.CreateQuestionRequestDTO
+type CreateQuestionRequest struct {
+	Conditional *ConditionalLogic      `json:"conditional,omitempty"`
+	Description *string                `json:"description,omitempty"`
+	Label       string                 `json:"label"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	Options     []QuestionOption       `json:"options,omitempty"`
+	Order       *int                   `json:"order,omitempty"`
+	Required    *bool                  `json:"required,omitempty"`
+	Type        string                 `json:"type"`
+	Validation  *QuestionValidation    `json:"validation,omitempty"`
+}
+
+// Form is generated from dto.FormResponseDTO
+type Form struct {
+	AllowMultiple bool            `json:"allowMultiple,omitempty"`
+	Category      string          `json:"category,omitempty"`
+	CreatedAt     time.Time       `json:"createdAt,omitempty"`
+	CreatedBy     *UserInfo       `json:"createdBy,omitempty"`
+	Description   string          `json:"description,omitempty"`
+	ExpiresAt     time.Time       `json:"expiresAt,omitempty"`
+	ID            string          `json:"id,omitempty"`
+	IsAnonymous   bool            `json:"isAnonymous,omitempty"`
+	IsPublic      bool            `json:"isPublic,omitempty"`
+	PublishedAt   time.Time       `json:"publishedAt,omitempty"`
+	Questions     []Question      `json:"questions,omitempty"`
+	Settings      *FormSettings   `json:"settings,omitempty"`
+	Statistics    *FormStatistics `json:"statistics,omitempty"`
+	Status        string          `json:"status,omitempty"`
+	Tags          []string        `json:"tags,omitempty"`
+	Title         string          `json:"title,omitempty"`
+	UpdatedAt     time.Time       `json:"updatedAt,omitempty"`
+}
+
+// FormSettings is generated from dto.FormSettingsDTO
+type FormSettings struct {
+	AllowDrafts        bool                   `json:"allowDrafts,omitempty"`
+	CollectEmail       bool                   `json:"collectEmail,omitempty"`
+	CustomCSS          string                 `json:"customCss,omitempty"`
+	Metadata           map[string]interface{} `json:"metadata,omitempty"`
+	NotifyOnSubmission bool                   `json:"notifyOnSubmission,omitempty"`
+	RedirectURL        string                 `json:"redirectUrl,omitempty"`
+	RequireLogin       bool                   `json:"requireLogin,omitempty"`
+	ShowProgressBar    bool                   `json:"showProgressBar,omitempty"`
+	ThankYouMessage    string                 `json:"thankYouMessage,omitempty"`
+}
+
+// FormStatistics is generated from dto.FormStatisticsDTO
+type FormStatistics struct {
+	AverageTimeSeconds int     `json:"averageTimeSeconds,omitempty"`
+	CompletionRate     float64 `json:"completionRate,omitempty"`
+	LastResponse       string  `json:"lastResponse,omitempty"`
+	ResponseRate       float64 `json:"responseRate,omitempty"`
+	TotalResponses     int     `json:"totalResponses,omitempty"`
+	UniqueResponders   int     `json:"uniqueResponders,omitempty"`
+}
+
+// FormSummary is generated from dto.FormSummaryDTO
+type FormSummary struct {
+	CreatedAt   time.Time       `json:"createdAt,omitempty"`
+	CreatedBy   *UserInfo       `json:"createdBy,omitempty"`
+	Description string          `json:"description,omitempty"`
+	ExpiresAt   time.Time       `json:"expiresAt,omitempty"`
+	ID          string          `json:"id,omitempty"`
+	IsPublic    bool            `json:"isPublic,omitempty"`
+	PublishedAt time.Time       `json:"publishedAt,omitempty"`
+	Statistics  *FormStatistics `json:"statistics,omitempty"`
+	Status      string          `json:"status,omitempty"`
+	Title       string          `json:"title,omitempty"`
+	UpdatedAt   time.Time       `json:"updatedAt,omitempty"`
+}
+
+// Pagination is generated from dto.Pagination
+type Pagination struct {
+	HasNext    bool `json:"hasNext,omitempty"`
+	HasPrev    bool `json:"hasPrev,omitempty"`
+	Page       int  `json:"page,omitempty"`
+	PageSize   int  `json:"pageSize,omitempty"`
+	Total      int  `json:"total,omitempty"`
+	TotalPages int  `json:"totalPages,omitempty"`
+}
+
+// PublishFormRequest is generated from dto.PublishFormRequestDTO
+type PublishFormRequest struct {
+	Message           *string    `json:"message,omitempty"`
+	NotifySubscribers *bool      `json:"notifySubscribers,omitempty"`
+	ScheduleAt        *time.Time `json:"scheduleAt,omitempty"`
+}
+
+// Question is generated from dto.QuestionResponseDTO
+type Question struct {
+	Conditional *ConditionalLogic      `json:"conditional,omitempty"`
+	CreatedAt   time.Time              `json:"createdAt,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	ID          string                 `json:"id,omitempty"`
+	Label       string                 `json:"label,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	Options     []QuestionOption       `json:"options,omitempty"`
+	Order       int                    `json:"order,omitempty"`
+	Required    bool                   `json:"required,omitempty"`
+	Type        string                 `json:"type,omitempty"`
+	UpdatedAt   time.Time              `json:"updatedAt,omitempty"`
+	Validation  *QuestionValidation    `json:"validation,omitempty"`
+}
+
+// QuestionOption is generated from dto.QuestionOptionDTO
+type QuestionOption struct {
+	Label string `json:"label"`
+	Order int    `json:"order,omitempty"`
+	Value string `json:"value"`
+}
+
+// QuestionValidation is generated from dto.QuestionValidationDTO
+type QuestionValidation struct {
+	AllowedTypes []string `json:"allowedTypes,omitempty"`
+	MaxFileSize  int      `json:"maxFileSize,omitempty"`
+	MaxLength    int      `json:"maxLength,omitempty"`
+	MaxValue     float64  `json:"maxValue,omitempty"`
+	MinLength    int      `json:"minLength,omitempty"`
+	MinValue     float64  `json:"minValue,omitempty"`
+	Pattern      string   `json:"pattern,omitempty"`
+}
+
+// UpdateFormRequest is generated from dto.UpdateFormRequestDTO
+type UpdateFormRequest struct {
+	AllowMultiple *bool         `json:"allowMultiple,omitempty"`
+	Category      *string       `json:"category,omitempty"`
+	Description   *string       `json:"description,omitempty"`
+	ExpiresAt     *time.Time    `json:"expiresAt,omitempty"`
+	IsAnonymous   *bool         `json:"isAnonymous,omitempty"`
+	IsPublic      *bool         `json:"isPublic,omitempty"`
+	Settings      *FormSettings `json:"settings,omitempty"`
+	Tags          []string      `json:"tags,omitempty"`
+	Title         *string       `json:"title,omitempty"`
+}
+
+// UserInfo is generated from dto.UserInfoDTO
+type UserInfo struct {
+	Avatar   string `json:"avatar,omitempty"`
+	Email    string `json:"email,omitempty"`
+	ID       string `json:"id,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Username string `json:"username,omitempty"`
+}
@@ -0,0 +1,185 @@
+// Command gen generates the Go types of the form resources from the Swagger
+// spec of the Form Service. Run it through go generate in the client package.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+// definitions maps the generated Swagger definitions to Go type names
+var definitions = map[string]string{
+	"dto.FormResponseDTO":          "Form",
+	"dto.FormSummaryDTO":           "FormSummary",
+	"dto.FormSettingsDTO":          "FormSettings",
+	"dto.FormStatisticsDTO":        "FormStatistics",
+	"dto.QuestionResponseDTO":      "Question",
+	"dto.QuestionOptionDTO":        "QuestionOption",
+	"dto.QuestionValidationDTO":    "QuestionValidation",
+	"dto.ConditionalLogicDTO":      "ConditionalLogic",
+	"dto.ConditionDTO":             "Condition",
+	"dto.UserInfoDTO":              "UserInfo",
+	"dto.CreateFormRequestDTO":     "CreateFormRequest",
+	"dto.CreateQuestionRequestDTO": "CreateQuestionRequest",
+	"dto.UpdateFormRequestDTO":     "UpdateFormRequest",
+	"dto.PublishFormRequestDTO":    "PublishFormRequest",
+	"dto.Pagination":               "Pagination",
+}
+
+// initialisms are kept upper case in Go field names
+var initialisms = map[string]string{"id": "ID", "url": "URL", "css": "CSS", "api": "API"}
+
+type schema struct {
+	Type                 string             `json:"type"`
+	Format               string             `json:"format"`
+	Ref                  string             `json:"$ref"`
+	Description          string             `json:"description"`
+	Items                *schema            `json:"items"`
+	Properties           map[string]*schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties json.RawMessage    `json:"additionalProperties"`
+}
+
+type spec struct {
+	Definitions map[string]*schema `json:"definitions"`
+}
+
+func main() {
+	specPath := flag.String("spec", "", "path to swagger.json")
+	out := flag.String("out", "", "output file")
+	flag.Parse()
+
+	data, err := os.ReadFile(*specPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var s spec
+	if err := json.Unmarshal(data, &s); err != nil {
+		log.Fatalf("failed to parse %s: %v", *specPath, err)
+	}
+
+	names := make([]string, 0, len(definitions))
+	for name := range definitions {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return definitions[names[i]] < definitions[names[j]] })
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by internal/gen from apps/form-service/docs/swagger.json. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package client\n\nimport \"time\"\n\n")
+	for _, name := range names {
+		def, ok := s.Definitions[name]
+		if !ok {
+			log.Fatalf("definition %s not found in %s", name, *specPath)
+		}
+		writeStruct(&buf, definitions[name], name, def)
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatalf("failed to format generated code: %v\n%s", err, buf.Bytes())
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func writeStruct(buf *bytes.Buffer, goName, specName string, def *schema) {
+	request := strings.HasSuffix(goName, "Request")
+	required := make(map[string]bool, len(def.Required))
+	for _, name := range def.Required {
+		required[name] = true
+	}
+
+	fmt.Fprintf(buf, "// %s is generated from %s\n", goName, specName)
+	fmt.Fprintf(buf, "type %s struct {\n", goName)
+
+	props := make([]string, 0, len(def.Properties))
+	for name := range def.Properties {
+		props = append(props, name)
+	}
+	sort.Strings(props)
+
+	for _, name := range props {
+		prop := def.Properties[name]
+		goType := typeOf(name, prop)
+		// Optional request fields are pointers so zero values can be sent
+		if request && !required[name] && isScalar(goType) {
+			goType = "*" + goType
+		}
+		tag := name
+		if !required[name] {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(buf, "\t%s %s `json:%q`\n", fieldName(name), goType, tag)
+	}
+	fmt.Fprintf(buf, "}\n\n")
+}
+
+func typeOf(name string, s *schema) string {
+	if s.Ref != "" {
+		ref := strings.TrimPrefix(s.Ref, "#/definitions/")
+		if goName, ok := definitions[ref]; ok {
+			return "*" + goName
+		}
+		return "map[string]interface{}"
+	}
+
+	switch s.Type {
+	case "string":
+		if s.Format == "date-time" || strings.HasSuffix(name, "At") {
+			return "time.Time"
+		}
+		return "string"
+	case "integer":
+		return "int"
+	case "number":
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		if s.Items == nil {
+			return "[]interface{}"
+		}
+		item := typeOf("", s.Items)
+		return "[]" + strings.TrimPrefix(item, "*")
+	case "object":
+		return "map[string]interface{}"
+	default:
+		return "interface{}"
+	}
+}
+
+func isScalar(goType string) bool {
+	switch goType {
+	case "string", "int", "float64", "bool", "time.Time":
+		return true
+	}
+	return false
+}
+
+func fieldName(name string) string {
+	var parts []string
+	start := 0
+	for i := 1; i <= len(name); i++ {
+		if i == len(name) || (name[i] >= 'A' && name[i] <= 'Z') {
+			parts = append(parts, name[start:i])
+			start = i
+		}
+	}
+	for i, part := range parts {
+		if upper, ok := initialisms[strings.ToLower(part)]; ok {
+			parts[i] = upper
+			continue
+		}
+		parts[i] = strings.ToUpper(part[:1]) + part[1:]
+	}
+	return strings.Join(parts, "")
+}
@@ -0,0 +1,161 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ResponsesService submits and reads form responses through the Response
+// Service
+type ResponsesService struct {
+	client *Client
+}
+
+// Answer is the answer to one question
+type Answer struct {
+	QuestionID   string      `json:"questionId"`
+	QuestionType string      `json:"questionType,omitempty"`
+	Value        interface{} `json:"value"`
+	Files        []string    `json:"files,omitempty"`
+}
+
+// SubmitResponseRequest submits a response to a form
+type SubmitResponseRequest struct {
+	FormID          string                 `json:"formId"`
+	RespondentEmail string                 `json:"respondentEmail,omitempty"`
+	RespondentName  string                 `json:"respondentName,omitempty"`
+	Responses       []Answer               `json:"responses"`
+	Metadata        map[string]interface{} `json:"metadata,omitempty"`
+	IsDraft         bool                   `json:"isDraft,omitempty"`
+	IsPartial       bool                   `json:"isPartial,omitempty"`
+}
+
+// Response is a submitted form response
+type Response struct {
+	ID              string                 `json:"id"`
+	FormID          string                 `json:"formId"`
+	FormTitle       string                 `json:"formTitle,omitempty"`
+	RespondentID    string                 `json:"respondentId,omitempty"`
+	RespondentEmail string                 `json:"respondentEmail,omitempty"`
+	RespondentName  string                 `json:"respondentName,omitempty"`
+	IsAnonymous     bool                   `json:"isAnonymous"`
+	Responses       []Answer               `json:"responses"`
+	Status          string                 `json:"status"`
+	IsDraft         bool                   `json:"isDraft"`
+	IsPartial       bool                   `json:"isPartial"`
+	Metadata        map[string]interface{} `json:"metadata,omitempty"`
+	SubmittedAt     time.Time              `json:"submittedAt"`
+	UpdatedAt       time.Time              `json:"updatedAt"`
+	FormVersion     int                    `json:"formVersion,omitempty"`
+	Revision        int                    `json:"revision,omitempty"`
+	EditableUntil   *time.Time             `json:"editableUntil,omitempty"`
+	LastEditedAt    *time.Time             `json:"lastEditedAt,omitempty"`
+	// EditToken is only returned by Submit, for forms with an edit window
+	EditToken string `json:"editToken,omitempty"`
+}
+
+// ResponseSummary is a response as listed by List
+type ResponseSummary struct {
+	ID              string    `json:"id"`
+	FormID          string    `json:"formId"`
+	FormTitle       string    `json:"formTitle,omitempty"`
+	RespondentEmail string    `json:"respondentEmail,omitempty"`
+	RespondentName  string    `json:"respondentName,omitempty"`
+	Status          string    `json:"status"`
+	SubmittedAt     time.Time `json:"submittedAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+	ResponseCount   int       `json:"responseCount"`
+}
+
+// ListResponsesOptions filters and pages the responses returned by List
+type ListResponsesOptions struct {
+	FormID          string
+	RespondentEmail string
+	Status          string
+	StartDate       time.Time
+	EndDate         time.Time
+	Search          string
+	SortBy          string
+	SortOrder       string
+	Page            int
+	Limit           int
+}
+
+func (o *ListResponsesOptions) values() url.Values {
+	q := url.Values{}
+	if o == nil {
+		return q
+	}
+	for key, value := range map[string]string{
+		"formId":          o.FormID,
+		"respondentEmail": o.RespondentEmail,
+		"status":          o.Status,
+		"search":          o.Search,
+		"sortBy":          o.SortBy,
+		"sortOrder":       o.SortOrder,
+	} {
+		if value != "" {
+			q.Set(key, value)
+		}
+	}
+	if !o.StartDate.IsZero() {
+		q.Set("startDate", o.StartDate.Format(time.RFC3339))
+	}
+	if !o.EndDate.IsZero() {
+		q.Set("endDate", o.EndDate.Format(time.RFC3339))
+	}
+	if o.Page > 0 {
+		q.Set("page", strconv.Itoa(o.Page))
+	}
+	if o.Limit > 0 {
+		q.Set("limit", strconv.Itoa(o.Limit))
+	}
+	return q
+}
+
+// ResponseList is a page of responses
+type ResponseList struct {
+	Responses  []ResponseSummary `json:"responses"`
+	Pagination struct {
+		CurrentPage  int  `json:"currentPage"`
+		TotalPages   int  `json:"totalPages"`
+		TotalItems   int  `json:"totalItems"`
+		ItemsPerPage int  `json:"itemsPerPage"`
+		HasNext      bool `json:"hasNext"`
+		HasPrevious  bool `json:"hasPrevious"`
+	} `json:"pagination"`
+}
+
+// Submit submits a response. Submissions are never retried, so a failed call
+// may still have been recorded.
+func (s *ResponsesService) Submit(ctx context.Context, req *SubmitResponseRequest) (*Response, error) {
+	var response Response
+	if _, err := s.client.do(ctx, http.MethodPost, "/responses", nil, req, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// List returns the responses visible to the caller
+func (s *ResponsesService) List(ctx context.Context, opts *ListResponsesOptions) (*ResponseList, error) {
+	var list ResponseList
+	if _, err := s.client.do(ctx, http.MethodGet, "/responses", opts.values(), nil, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// Get returns a response
+func (s *ResponsesService) Get(ctx context.Context, id string) (*Response, error) {
+	if id == "" {
+		return nil, errMissingID
+	}
+	var response Response
+	if _, err := s.client.do(ctx, http.MethodGet, pathf("/responses/%s", id), nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
@@ -0,0 +1,212 @@
+// Package errreport reports the errors and panics of the services to an
+// error tracker. Services report through the Reporter interface, whose
+// implementation is chosen by configuration: Sentry, the Noop reporter,
+// which only keeps the last events for tests, or any other a service
+// provides, such as the OpenTelemetry reporter of the event bus.
+//
+// Every event gets an ID returned to the caller, which services put in the
+// error responses so support can find the event a user reports. Events are
+// rate-limited per fingerprint, so an error repeated in a hot loop is
+// reported a few times rather than flooding the tracker.
+package errreport
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Providers of Config
+const (
+	ProviderNoop   = "noop"
+	ProviderSentry = "sentry"
+)
+
+// Event is an error or a panic reported to the tracker
+type Event struct {
+	// ID is set by Report
+	ID   string
+	Time time.Time
+	// Err is the error reported; a panic is reported as an error of its value
+	Err   error
+	Panic bool
+	// Stack is the stack trace of a panic
+	Stack string
+	// Fingerprint groups the events of the same error; Report derives it
+	// from the error and its context when empty
+	Fingerprint string
+
+	// Request is set for the errors of HTTP requests
+	Request *Request
+	// Message is set for the errors of events consumed from Kafka
+	Message *Message
+	// Tags are indexed by the tracker, Extra only shown with the event
+	Tags  map[string]string
+	Extra map[string]interface{}
+}
+
+// Request is the context of an HTTP request that failed
+type Request struct {
+	// Route is the pattern of the route, e.g. /api/v1/forms/:id
+	Route         string
+	Method        string
+	Path          string
+	Status        int
+	CorrelationID string
+	UserID        string
+	// Headers are sanitized with SanitizeHeaders
+	Headers map[string]string
+}
+
+// Message is the context of a Kafka message that failed to be processed
+type Message struct {
+	ID        string
+	EventType string
+	Topic     string
+	Partition int32
+	Offset    int64
+	Group     string
+}
+
+type messageKey struct{}
+
+// WithMessage returns a context carrying the Kafka message being processed,
+// for the errors reported further down to be attached to it
+func WithMessage(ctx context.Context, message *Message) context.Context {
+	return context.WithValue(ctx, messageKey{}, message)
+}
+
+// MessageFrom returns the Kafka message carried by ctx, or nil
+func MessageFrom(ctx context.Context) *Message {
+	message, _ := ctx.Value(messageKey{}).(*Message)
+	return message
+}
+
+// Reporter reports events to an error tracker
+type Reporter interface {
+	// Report reports the event and returns its ID. Reporting never blocks
+	// on the tracker.
+	Report(ctx context.Context, event *Event) string
+	// Flush waits up to timeout for the events being sent
+	Flush(timeout time.Duration) bool
+}
+
+// Config selects and configures the reporter
+type Config struct {
+	// Provider is noop or sentry
+	Provider    string
+	Service     string
+	Environment string
+	Release     string
+	// DSN is the Sentry DSN
+	DSN string
+	// Burst events of a fingerprint are reported per Interval; SampleRate
+	// of those beyond it are
+	Burst      int
+	Interval   time.Duration
+	SampleRate float64
+}
+
+// New creates the reporter of the configuration, rate-limited per
+// fingerprint
+func New(cfg Config) (Reporter, error) {
+	var reporter Reporter
+	switch cfg.Provider {
+	case "", ProviderNoop:
+		reporter = NewNoop()
+	case ProviderSentry:
+		sentry, err := NewSentry(SentryConfig{DSN: cfg.DSN, Service: cfg.Service, Environment: cfg.Environment, Release: cfg.Release})
+		if err != nil {
+			return nil, err
+		}
+		reporter = sentry
+	default:
+		return nil, fmt.Errorf("unknown error reporting provider %q", cfg.Provider)
+	}
+	return Limit(reporter, LimitConfig{Burst: cfg.Burst, Interval: cfg.Interval, SampleRate: cfg.SampleRate}), nil
+}
+
+// NewID returns a new event ID, 32 hexadecimal digits like the IDs of Sentry
+func NewID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("%032x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// variable matches the parts of error messages that change between
+// occurrences of the same error: UUIDs, hexadecimal IDs and numbers
+var variable = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|\b[0-9a-fA-F]{16,}\b|\d+`)
+
+// Fingerprint returns the fingerprint of the event: the one it was given,
+// or a hash of where it happened and of its error without the IDs and
+// numbers that change between occurrences
+func Fingerprint(event *Event) string {
+	if event.Fingerprint != "" {
+		return event.Fingerprint
+	}
+
+	parts := []string{}
+	if event.Request != nil {
+		parts = append(parts, event.Request.Method, event.Request.Route)
+	}
+	if event.Message != nil {
+		parts = append(parts, event.Message.Topic, event.Message.EventType)
+	}
+	if event.Err != nil {
+		parts = append(parts, fmt.Sprintf("%T", event.Err), variable.ReplaceAllString(event.Err.Error(), "?"))
+	}
+	if event.Panic {
+		parts = append(parts, "panic", panicFrame(event.Stack))
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// panicFrame returns the function that panicked: the first frame of the
+// stack after the runtime's
+func panicFrame(stack string) string {
+	lines := strings.Split(stack, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "panic(") && i+2 < len(lines) {
+			return lines[i+2]
+		}
+	}
+	return ""
+}
+
+// sensitive names the headers whose values are never reported
+var sensitive = []string{"authorization", "cookie", "token", "secret", "password", "signature", "api-key", "apikey"}
+
+// SanitizeHeaders returns the headers of a request with the values of the
+// credentials, cookies, tokens and signatures redacted
+func SanitizeHeaders(headers http.Header) map[string]string {
+	sanitized := make(map[string]string, len(headers))
+	for name, values := range headers {
+		value := strings.Join(values, ", ")
+		lower := strings.ToLower(name)
+		for _, s := range sensitive {
+			if strings.Contains(lower, s) {
+				value = "[redacted]"
+				break
+			}
+		}
+		sanitized[name] = value
+	}
+	return sanitized
+}
+
+// PanicError returns the error a panic is reported as
+func PanicError(recovered interface{}) error {
+	if err, ok := recovered.(error); ok {
+		return fmt.Errorf("panic: %w", err)
+	}
+	return fmt.Errorf("panic: %v", recovered)
+}
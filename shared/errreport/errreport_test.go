@@ -0,0 +1,127 @@
+package errreport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLimit(t *testing.T) {
+	ctx := context.Background()
+	noop := NewNoop()
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	reporter := Limit(noop, LimitConfig{Burst: 2, Interval: time.Minute}).(*limiter)
+	reporter.now = func() time.Time { return now }
+	report := func(err error) string {
+		return reporter.Report(ctx, &Event{Err: err, Request: &Request{Method: "GET", Route: "/api/v1/forms/:id"}})
+	}
+
+	first := report(errors.New("form 6f1c2a9e-0b7d-4f44-9a43-1b2c3d4e5f60 not loaded: timeout after 30s"))
+	second := report(errors.New("form 0d6b1c52-2f8e-4d1a-8c5e-7a6b5c4d3e2f not loaded: timeout after 31s"))
+	if first == "" || second == "" || first == second {
+		t.Fatalf("IDs of the burst = %q, %q; want two IDs", first, second)
+	}
+	// The same error in a hot loop is dropped, with the ID of the last
+	// event reported
+	for i := 0; i < 5; i++ {
+		if id := report(errors.New("form 1 not loaded: timeout after 30s")); id != second {
+			t.Fatalf("dropped event ID = %q, want %q", id, second)
+		}
+	}
+	// Other errors are not
+	if id := report(errors.New("database is closed")); id == "" || id == second {
+		t.Errorf("another error: ID = %q, want a new one", id)
+	}
+	if got := len(noop.Events()); got != 3 {
+		t.Fatalf("reported %d events, want 3", got)
+	}
+
+	// The next interval reports it again, with the count of those dropped
+	now = now.Add(time.Minute)
+	report(errors.New("form 2 not loaded: timeout after 30s"))
+	events := noop.Events()
+	if last := events[len(events)-1]; last.Extra["suppressed"] != 5 || last.Fingerprint != events[0].Fingerprint {
+		t.Errorf("event after the interval = %+v, want 5 suppressed of the first fingerprint", last)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	panicked := &Event{Err: PanicError("nil map"), Panic: true, Stack: "goroutine 1 [running]:\npanic({0x1, 0x2})\n\t/usr/local/go/src/runtime/panic.go:770\nmain.handler(...)\n\t/app/main.go:12"}
+	elsewhere := &Event{Err: PanicError("nil map"), Panic: true, Stack: "goroutine 7 [running]:\npanic({0x1, 0x2})\n\t/usr/local/go/src/runtime/panic.go:770\nmain.other(...)\n\t/app/main.go:40"}
+	if Fingerprint(panicked) == Fingerprint(elsewhere) {
+		t.Error("panics in two functions have the same fingerprint")
+	}
+	topic := &Event{Err: errors.New("bad"), Message: &Message{Topic: "app.form.updated", Offset: 1}}
+	offset := &Event{Err: errors.New("bad"), Message: &Message{Topic: "app.form.updated", Offset: 2}}
+	if Fingerprint(topic) != Fingerprint(offset) {
+		t.Error("the offset changed the fingerprint")
+	}
+	if Fingerprint(&Event{Fingerprint: "given"}) != "given" {
+		t.Error("the fingerprint given was replaced")
+	}
+}
+
+func TestSanitizeHeaders(t *testing.T) {
+	headers := http.Header{
+		"Authorization":       {"Bearer abc"},
+		"Cookie":              {"session=1"},
+		"X-Api-Key":           {"xf_live_1"},
+		"X-Webhook-Signature": {"sha256=1"},
+		"X-Request-Id":        {"req-1"},
+		"Accept":              {"text/html", "application/json"},
+	}
+	sanitized := SanitizeHeaders(headers)
+	for _, name := range []string{"Authorization", "Cookie", "X-Api-Key", "X-Webhook-Signature"} {
+		if sanitized[name] != "[redacted]" {
+			t.Errorf("%s = %q, want it redacted", name, sanitized[name])
+		}
+	}
+	if sanitized["X-Request-Id"] != "req-1" || sanitized["Accept"] != "text/html, application/json" {
+		t.Errorf("sanitized = %v, want the other headers kept", sanitized)
+	}
+}
+
+func TestSentry(t *testing.T) {
+	received := make(chan map[string]interface{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/42/store/" || !strings.Contains(r.Header.Get("X-Sentry-Auth"), "sentry_key=public") {
+			t.Errorf("request to %s with auth %q", r.URL.Path, r.Header.Get("X-Sentry-Auth"))
+		}
+		var event map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&event)
+		received <- event
+	}))
+	defer server.Close()
+
+	reporter, err := NewSentry(SentryConfig{DSN: strings.Replace(server.URL, "://", "://public@", 1) + "/42", Service: "form-service"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := reporter.Report(context.Background(), &Event{
+		Err:     errors.New("boom"),
+		Panic:   true,
+		Request: &Request{Method: "POST", Route: "/api/v1/forms", CorrelationID: "req-1", UserID: "user-1"},
+	})
+	if !reporter.Flush(time.Second) {
+		t.Fatal("Flush timed out")
+	}
+
+	event := <-received
+	tags, _ := event["tags"].(map[string]interface{})
+	user, _ := event["user"].(map[string]interface{})
+	if event["event_id"] != id || event["level"] != "fatal" || event["transaction"] != "POST /api/v1/forms" {
+		t.Errorf("event = %v", event)
+	}
+	if tags["service"] != "form-service" || tags["correlation_id"] != "req-1" || user["id"] != "user-1" {
+		t.Errorf("tags = %v, user = %v", tags, user)
+	}
+
+	if _, err := NewSentry(SentryConfig{DSN: "https://sentry.example.com/42"}); err == nil {
+		t.Error("created a reporter for a DSN without a key")
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/Mir00r/X-Form-Backend/shared/errreport
+
+go 1.21.0
@@ -0,0 +1,125 @@
+package errreport
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Defaults of LimitConfig
+const (
+	DefaultBurst    = 10
+	DefaultInterval = time.Minute
+)
+
+// maxWindows bounds the fingerprints tracked; past it the windows that ended
+// are dropped
+const maxWindows = 10000
+
+// LimitConfig bounds the events reported per fingerprint
+type LimitConfig struct {
+	// Burst events of a fingerprint are reported per Interval
+	Burst    int
+	Interval time.Duration
+	// SampleRate is the fraction of the events beyond the burst reported
+	// anyway, none by default
+	SampleRate float64
+}
+
+// limiter reports the first events of each fingerprint in every interval
+// and drops the rest, which get the ID of the last event reported for it
+type limiter struct {
+	next   Reporter
+	cfg    LimitConfig
+	now    func() time.Time
+	random func() float64
+
+	mu      sync.Mutex
+	windows map[string]*window
+}
+
+// window counts the events of a fingerprint in an interval
+type window struct {
+	start      time.Time
+	reported   int
+	suppressed int
+	lastID     string
+}
+
+// Limit rate-limits the events reported to next per fingerprint. An event
+// dropped gets the ID of the last one reported with its fingerprint, which
+// support finds in the tracker; the next one reported carries the number
+// dropped since in its suppressed extra.
+func Limit(next Reporter, cfg LimitConfig) Reporter {
+	if cfg.Burst <= 0 {
+		cfg.Burst = DefaultBurst
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	return &limiter{next: next, cfg: cfg, now: time.Now, random: rand.Float64, windows: make(map[string]*window)}
+}
+
+// Report reports the event unless its fingerprint is over the limit
+func (l *limiter) Report(ctx context.Context, event *Event) string {
+	now := l.now()
+	if event.Time.IsZero() {
+		event.Time = now
+	}
+	event.Fingerprint = Fingerprint(event)
+
+	l.mu.Lock()
+	w := l.window(event.Fingerprint, now)
+	if w.reported >= l.cfg.Burst && l.random() >= l.cfg.SampleRate {
+		w.suppressed++
+		id := w.lastID
+		l.mu.Unlock()
+		return id
+	}
+	w.reported++
+	suppressed := w.suppressed
+	w.suppressed = 0
+	l.mu.Unlock()
+
+	if suppressed > 0 {
+		if event.Extra == nil {
+			event.Extra = map[string]interface{}{}
+		}
+		event.Extra["suppressed"] = suppressed
+	}
+	id := l.next.Report(ctx, event)
+
+	l.mu.Lock()
+	w.lastID = id
+	l.mu.Unlock()
+	return id
+}
+
+// Flush flushes the reporter limited
+func (l *limiter) Flush(timeout time.Duration) bool {
+	return l.next.Flush(timeout)
+}
+
+// window returns the current window of the fingerprint. A new one keeps the
+// count of events dropped and the last ID of the previous one.
+func (l *limiter) window(fingerprint string, now time.Time) *window {
+	w, ok := l.windows[fingerprint]
+	if ok && now.Sub(w.start) < l.cfg.Interval {
+		return w
+	}
+	if !ok && len(l.windows) >= maxWindows {
+		for key, old := range l.windows {
+			if now.Sub(old.start) >= l.cfg.Interval {
+				delete(l.windows, key)
+			}
+		}
+	}
+
+	next := &window{start: now}
+	if ok {
+		next.suppressed, next.lastID = w.suppressed, w.lastID
+	}
+	l.windows[fingerprint] = next
+	return next
+}
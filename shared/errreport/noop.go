@@ -0,0 +1,52 @@
+package errreport
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// noopKept is the number of events the Noop reporter keeps
+const noopKept = 100
+
+// Noop reports events nowhere. It keeps the last 100 for tests to assert
+// what was reported.
+type Noop struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+// NewNoop creates a Noop reporter
+func NewNoop() *Noop {
+	return &Noop{}
+}
+
+// Report keeps the event and returns a new ID
+func (n *Noop) Report(_ context.Context, event *Event) string {
+	if event.ID == "" {
+		event.ID = NewID()
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.events = append(n.events, *event)
+	if len(n.events) > noopKept {
+		n.events = n.events[len(n.events)-noopKept:]
+	}
+	return event.ID
+}
+
+// Flush returns at once
+func (n *Noop) Flush(time.Duration) bool {
+	return true
+}
+
+// Events returns the events reported, oldest first
+func (n *Noop) Events() []Event {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]Event(nil), n.events...)
+}
@@ -0,0 +1,241 @@
+package errreport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sentryQueueSize bounds the events waiting to be sent; past it events are
+// dropped rather than blocking the requests reporting them
+const sentryQueueSize = 256
+
+// SentryConfig configures the Sentry reporter
+type SentryConfig struct {
+	// DSN is the Sentry DSN, https://<key>@<host>/<project>
+	DSN         string
+	Service     string
+	Environment string
+	Release     string
+	// Client sends the events, with a 5s timeout by default
+	Client *http.Client
+}
+
+// Sentry reports events to the store API of Sentry, in the background
+type Sentry struct {
+	endpoint   string
+	auth       string
+	cfg        SentryConfig
+	serverName string
+
+	queue   chan *sentryEvent
+	pending sync.WaitGroup
+}
+
+// NewSentry creates a Sentry reporter sending events to the project of the
+// DSN
+func NewSentry(cfg SentryConfig) (*Sentry, error) {
+	dsn, err := url.Parse(cfg.DSN)
+	if err != nil || dsn.User == nil || dsn.Host == "" {
+		return nil, fmt.Errorf("invalid Sentry DSN")
+	}
+	path := strings.TrimSuffix(dsn.Path, "/")
+	slash := strings.LastIndex(path, "/")
+	project := path[slash+1:]
+	if _, err := strconv.Atoi(project); err != nil {
+		return nil, fmt.Errorf("invalid Sentry DSN: no project ID")
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 5 * time.Second}
+	}
+
+	serverName, _ := os.Hostname()
+	s := &Sentry{
+		endpoint: fmt.Sprintf("%s://%s%s/api/%s/store/", dsn.Scheme, dsn.Host, path[:slash], project),
+		auth: fmt.Sprintf("Sentry sentry_version=7, sentry_client=x-form-errreport/1.0, sentry_key=%s",
+			dsn.User.Username()),
+		cfg:        cfg,
+		serverName: serverName,
+		queue:      make(chan *sentryEvent, sentryQueueSize),
+	}
+	go s.run()
+	return s, nil
+}
+
+// sentryEvent is an event in the format of the store API
+type sentryEvent struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   string                 `json:"timestamp"`
+	Level       string                 `json:"level"`
+	Platform    string                 `json:"platform"`
+	ServerName  string                 `json:"server_name,omitempty"`
+	Environment string                 `json:"environment,omitempty"`
+	Release     string                 `json:"release,omitempty"`
+	Transaction string                 `json:"transaction,omitempty"`
+	Fingerprint []string               `json:"fingerprint,omitempty"`
+	Exception   sentryExceptions       `json:"exception"`
+	Tags        map[string]string      `json:"tags,omitempty"`
+	Extra       map[string]interface{} `json:"extra,omitempty"`
+	User        *sentryUser            `json:"user,omitempty"`
+	Request     *sentryRequest         `json:"request,omitempty"`
+}
+
+type sentryExceptions struct {
+	Values []sentryException `json:"values"`
+}
+
+type sentryException struct {
+	Type      string          `json:"type"`
+	Value     string          `json:"value"`
+	Mechanism sentryMechanism `json:"mechanism"`
+}
+
+type sentryMechanism struct {
+	Type    string `json:"type"`
+	Handled bool   `json:"handled"`
+}
+
+type sentryUser struct {
+	ID string `json:"id"`
+}
+
+type sentryRequest struct {
+	URL     string            `json:"url,omitempty"`
+	Method  string            `json:"method,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// Report queues the event to be sent, dropping it when the queue is full
+func (s *Sentry) Report(_ context.Context, event *Event) string {
+	if event.ID == "" {
+		event.ID = NewID()
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	s.pending.Add(1)
+	select {
+	case s.queue <- s.convert(event):
+	default:
+		s.pending.Done()
+		log.Printf("Error reporting queue full, dropped event %s", event.ID)
+	}
+	return event.ID
+}
+
+// Flush waits up to timeout for the events queued to be sent
+func (s *Sentry) Flush(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		s.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// run sends the events queued
+func (s *Sentry) run() {
+	for event := range s.queue {
+		if err := s.send(event); err != nil {
+			log.Printf("Failed to report event %s to Sentry: %v", event.EventID, err)
+		}
+		s.pending.Done()
+	}
+}
+
+func (s *Sentry) send(event *sentryEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", s.auth)
+
+	resp, err := s.cfg.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sentry answered %s", resp.Status)
+	}
+	return nil
+}
+
+// convert converts an event to the format of the store API
+func (s *Sentry) convert(event *Event) *sentryEvent {
+	out := &sentryEvent{
+		EventID:     event.ID,
+		Timestamp:   event.Time.UTC().Format(time.RFC3339Nano),
+		Level:       "error",
+		Platform:    "go",
+		ServerName:  s.serverName,
+		Environment: s.cfg.Environment,
+		Release:     s.cfg.Release,
+		Fingerprint: []string{Fingerprint(event)},
+		Tags:        map[string]string{"service": s.cfg.Service},
+		Extra:       map[string]interface{}{},
+	}
+	exception := sentryException{Type: "error", Mechanism: sentryMechanism{Type: "generic", Handled: true}}
+	if event.Err != nil {
+		exception.Type, exception.Value = fmt.Sprintf("%T", event.Err), event.Err.Error()
+	}
+	if event.Panic {
+		out.Level = "fatal"
+		exception.Mechanism = sentryMechanism{Type: "panic", Handled: false}
+		out.Extra["stack"] = event.Stack
+	}
+	out.Exception.Values = []sentryException{exception}
+
+	if r := event.Request; r != nil {
+		out.Transaction = r.Method + " " + r.Route
+		out.Request = &sentryRequest{URL: r.Path, Method: r.Method, Headers: r.Headers}
+		out.Tags["route"] = r.Route
+		if r.Status != 0 {
+			out.Tags["status"] = strconv.Itoa(r.Status)
+		}
+		if r.CorrelationID != "" {
+			out.Tags["correlation_id"] = r.CorrelationID
+		}
+		if r.UserID != "" {
+			out.User = &sentryUser{ID: r.UserID}
+		}
+	}
+	if m := event.Message; m != nil {
+		out.Transaction = m.Topic
+		out.Tags["topic"] = m.Topic
+		out.Tags["event_type"] = m.EventType
+		out.Extra["message_id"] = m.ID
+		out.Extra["partition"] = m.Partition
+		out.Extra["offset"] = m.Offset
+		if m.Group != "" {
+			out.Tags["group"] = m.Group
+		}
+	}
+	for key, value := range event.Tags {
+		out.Tags[key] = value
+	}
+	for key, value := range event.Extra {
+		out.Extra[key] = value
+	}
+	return out
+}
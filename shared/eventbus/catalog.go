@@ -0,0 +1,87 @@
+package eventbus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CatalogRegistration declares the event types a service produces and
+// consumes in the event type catalog of the Event Bus Service
+type CatalogRegistration struct {
+	Service  string   `json:"service"`
+	Produces []string `json:"produces"`
+	Consumes []string `json:"consumes"`
+	// SchemaRef locates the schemas of the events the service produces
+	SchemaRef string `json:"schema_ref,omitempty"`
+	Team      string `json:"team,omitempty"`
+}
+
+// CatalogClient registers a service with the event type catalog, over the
+// HTTP API of the Event Bus Service
+type CatalogClient struct {
+	// BaseURL of the HTTP API, e.g. http://event-bus-service:8080
+	BaseURL string
+	// APIKey is sent as X-API-Key when the publish ACL is enabled
+	APIKey string
+	// HTTPClient defaults to a client with a 5s timeout
+	HTTPClient *http.Client
+}
+
+// Register creates or refreshes the registration of the service
+func (c *CatalogClient) Register(ctx context.Context, registration CatalogRegistration) error {
+	body, err := json.Marshal(registration)
+	if err != nil {
+		return fmt.Errorf("eventbus: failed to encode catalog registration: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(c.BaseURL, "/")+"/catalog/register", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("eventbus: failed to create catalog registration: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.APIKey != "" {
+		req.Header.Set("X-API-Key", c.APIKey)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 5 * time.Second}
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("eventbus: failed to register %s with the catalog: %w", registration.Service, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("eventbus: failed to register %s with the catalog: %s: %s", registration.Service, resp.Status, bytes.TrimSpace(message))
+	}
+	return nil
+}
+
+// KeepRegistered registers the service, then registers it again every
+// interval as its heartbeat, until ctx is done. Failures are passed to
+// onError, which may be nil, and do not stop the heartbeat. Services call it
+// in a goroutine at startup.
+func (c *CatalogClient) KeepRegistered(ctx context.Context, registration CatalogRegistration, interval time.Duration, onError func(error)) {
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := c.Register(ctx, registration); err != nil && onError != nil && ctx.Err() == nil {
+			onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,189 @@
+// Package eventbus is the Go client for the internal gRPC API of the Event Bus
+// Service. Services publishing events at high volume should prefer it over
+// the HTTP /events endpoints.
+//
+// The eventbusv1 package is generated from proto/eventbus/v1/eventbus.proto:
+//
+//	protoc -I proto \
+//	  --go_out=. --go_opt=module=github.com/Mir00r/X-Form-Backend/shared/eventbus \
+//	  --go-grpc_out=. --go-grpc_opt=module=github.com/Mir00r/X-Form-Backend/shared/eventbus \
+//	  proto/eventbus/v1/eventbus.proto
+package eventbus
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/Mir00r/X-Form-Backend/shared/eventbus/eventbusv1"
+)
+
+// Config configures the connection to the Event Bus Service
+type Config struct {
+	// Address of the gRPC listener, e.g. event-bus-service:9095
+	Address string
+
+	// Timeout applied to each call without a deadline; 5s when zero
+	Timeout time.Duration
+
+	// TLS enables transport security. Leave nil for plaintext connections.
+	TLS *TLSConfig
+}
+
+// TLSConfig holds the client side of TLS and mutual TLS
+type TLSConfig struct {
+	// CAFile verifies the server certificate; the system pool is used when empty
+	CAFile string
+	// CertFile and KeyFile are presented to servers requiring mTLS
+	CertFile   string
+	KeyFile    string
+	ServerName string
+}
+
+// Event is an event to publish
+type Event struct {
+	Type    string
+	Source  string
+	Subject string
+	Data    map[string]interface{}
+	Headers map[string]string
+	// Topic defaults to app.<Type> when empty
+	Topic string
+	Key   string
+}
+
+// Client publishes events to the Event Bus Service
+type Client struct {
+	conn    *grpc.ClientConn
+	api     eventbusv1.EventBusClient
+	timeout time.Duration
+}
+
+// NewClient connects to the Event Bus Service. The connection is established
+// lazily on the first call. Extra dial options are appended to the ones
+// derived from cfg.
+func NewClient(cfg Config, opts ...grpc.DialOption) (*Client, error) {
+	creds := insecure.NewCredentials()
+	if cfg.TLS != nil {
+		tlsConfig, err := cfg.TLS.build()
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	conn, err := grpc.NewClient(cfg.Address, append([]grpc.DialOption{grpc.WithTransportCredentials(creds)}, opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event bus client: %w", err)
+	}
+
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+
+	return &Client{
+		conn:    conn,
+		api:     eventbusv1.NewEventBusClient(conn),
+		timeout: timeout,
+	}, nil
+}
+
+// Publish publishes a single event
+func (c *Client) Publish(ctx context.Context, event Event) (*eventbusv1.PublishEventResponse, error) {
+	req, err := event.toProto()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.api.PublishEvent(ctx, req)
+}
+
+// PublishBatch publishes up to 1000 events in one call. Per-event failures
+// are reported in the response rather than as an error.
+func (c *Client) PublishBatch(ctx context.Context, events []Event) (*eventbusv1.PublishEventBatchResponse, error) {
+	req := &eventbusv1.PublishEventBatchRequest{
+		Events: make([]*eventbusv1.EventRequest, 0, len(events)),
+	}
+	for i, event := range events {
+		protoEvent, err := event.toProto()
+		if err != nil {
+			return nil, fmt.Errorf("event %d: %w", i, err)
+		}
+		req.Events = append(req.Events, protoEvent)
+	}
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.api.PublishEventBatch(ctx, req)
+}
+
+// Close closes the underlying connection
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+func (e Event) toProto() (*eventbusv1.EventRequest, error) {
+	req := &eventbusv1.EventRequest{
+		EventType: e.Type,
+		Source:    e.Source,
+		Subject:   e.Subject,
+		Headers:   e.Headers,
+		Topic:     e.Topic,
+		Key:       e.Key,
+	}
+	if e.Data != nil {
+		data, err := structpb.NewStruct(e.Data)
+		if err != nil {
+			return nil, fmt.Errorf("event data can't be encoded: %w", err)
+		}
+		req.Data = data
+	}
+	return req, nil
+}
+
+func (t *TLSConfig) build() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName: t.ServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read event bus CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", t.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if t.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load event bus client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
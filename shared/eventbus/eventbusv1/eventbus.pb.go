@@ -0,0 +1,467 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        (unknown)
+// source: eventbus/v1/eventbus.proto
+
+package eventbusv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// EventRequest mirrors the JSON EventRequest accepted over HTTP
+type EventRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	EventType string                 `protobuf:"bytes,1,opt,name=event_type,json=eventType,proto3" json:"event_type,omitempty"`
+	Source    string                 `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
+	Subject   string                 `protobuf:"bytes,3,opt,name=subject,proto3" json:"subject,omitempty"`
+	Data      *structpb.Struct       `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
+	Headers   map[string]string      `protobuf:"bytes,5,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Topic defaults to app.<event_type> when empty
+	Topic         string `protobuf:"bytes,6,opt,name=topic,proto3" json:"topic,omitempty"`
+	Key           string `protobuf:"bytes,7,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventRequest) Reset() {
+	*x = EventRequest{}
+	mi := &file_eventbus_v1_eventbus_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventRequest) ProtoMessage() {}
+
+func (x *EventRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_eventbus_v1_eventbus_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventRequest.ProtoReflect.Descriptor instead.
+func (*EventRequest) Descriptor() ([]byte, []int) {
+	return file_eventbus_v1_eventbus_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *EventRequest) GetEventType() string {
+	if x != nil {
+		return x.EventType
+	}
+	return ""
+}
+
+func (x *EventRequest) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *EventRequest) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *EventRequest) GetData() *structpb.Struct {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *EventRequest) GetHeaders() map[string]string {
+	if x != nil {
+		return x.Headers
+	}
+	return nil
+}
+
+func (x *EventRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *EventRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type PublishEventResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EventId       string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	Topic         string                 `protobuf:"bytes,2,opt,name=topic,proto3" json:"topic,omitempty"`
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PublishEventResponse) Reset() {
+	*x = PublishEventResponse{}
+	mi := &file_eventbus_v1_eventbus_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PublishEventResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublishEventResponse) ProtoMessage() {}
+
+func (x *PublishEventResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_eventbus_v1_eventbus_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublishEventResponse.ProtoReflect.Descriptor instead.
+func (*PublishEventResponse) Descriptor() ([]byte, []int) {
+	return file_eventbus_v1_eventbus_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PublishEventResponse) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *PublishEventResponse) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *PublishEventResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+type PublishEventBatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Events        []*EventRequest        `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PublishEventBatchRequest) Reset() {
+	*x = PublishEventBatchRequest{}
+	mi := &file_eventbus_v1_eventbus_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PublishEventBatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublishEventBatchRequest) ProtoMessage() {}
+
+func (x *PublishEventBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_eventbus_v1_eventbus_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublishEventBatchRequest.ProtoReflect.Descriptor instead.
+func (*PublishEventBatchRequest) Descriptor() ([]byte, []int) {
+	return file_eventbus_v1_eventbus_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PublishEventBatchRequest) GetEvents() []*EventRequest {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+// EventResult reports the outcome of one event of a batch
+type EventResult struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Index   int32                  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	EventId string                 `protobuf:"bytes,2,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	Topic   string                 `protobuf:"bytes,3,opt,name=topic,proto3" json:"topic,omitempty"`
+	Status  string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
+	// Error is set when the event was rejected or failed to publish
+	Error         string `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventResult) Reset() {
+	*x = EventResult{}
+	mi := &file_eventbus_v1_eventbus_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventResult) ProtoMessage() {}
+
+func (x *EventResult) ProtoReflect() protoreflect.Message {
+	mi := &file_eventbus_v1_eventbus_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventResult.ProtoReflect.Descriptor instead.
+func (*EventResult) Descriptor() ([]byte, []int) {
+	return file_eventbus_v1_eventbus_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *EventResult) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *EventResult) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *EventResult) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *EventResult) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *EventResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type PublishEventBatchResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	TotalEvents      int32                  `protobuf:"varint,1,opt,name=total_events,json=totalEvents,proto3" json:"total_events,omitempty"`
+	SuccessfulEvents int32                  `protobuf:"varint,2,opt,name=successful_events,json=successfulEvents,proto3" json:"successful_events,omitempty"`
+	FailedEvents     int32                  `protobuf:"varint,3,opt,name=failed_events,json=failedEvents,proto3" json:"failed_events,omitempty"`
+	Results          []*EventResult         `protobuf:"bytes,4,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *PublishEventBatchResponse) Reset() {
+	*x = PublishEventBatchResponse{}
+	mi := &file_eventbus_v1_eventbus_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PublishEventBatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublishEventBatchResponse) ProtoMessage() {}
+
+func (x *PublishEventBatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_eventbus_v1_eventbus_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublishEventBatchResponse.ProtoReflect.Descriptor instead.
+func (*PublishEventBatchResponse) Descriptor() ([]byte, []int) {
+	return file_eventbus_v1_eventbus_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *PublishEventBatchResponse) GetTotalEvents() int32 {
+	if x != nil {
+		return x.TotalEvents
+	}
+	return 0
+}
+
+func (x *PublishEventBatchResponse) GetSuccessfulEvents() int32 {
+	if x != nil {
+		return x.SuccessfulEvents
+	}
+	return 0
+}
+
+func (x *PublishEventBatchResponse) GetFailedEvents() int32 {
+	if x != nil {
+		return x.FailedEvents
+	}
+	return 0
+}
+
+func (x *PublishEventBatchResponse) GetResults() []*EventResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+var File_eventbus_v1_eventbus_proto protoreflect.FileDescriptor
+
+const file_eventbus_v1_eventbus_proto_rawDesc = "" +
+	"\n" +
+	"\x1aeventbus/v1/eventbus.proto\x12\x11xform.eventbus.v1\x1a\x1cgoogle/protobuf/struct.proto\"\xb8\x02\n" +
+	"\fEventRequest\x12\x1d\n" +
+	"\n" +
+	"event_type\x18\x01 \x01(\tR\teventType\x12\x16\n" +
+	"\x06source\x18\x02 \x01(\tR\x06source\x12\x18\n" +
+	"\asubject\x18\x03 \x01(\tR\asubject\x12+\n" +
+	"\x04data\x18\x04 \x01(\v2\x17.google.protobuf.StructR\x04data\x12F\n" +
+	"\aheaders\x18\x05 \x03(\v2,.xform.eventbus.v1.EventRequest.HeadersEntryR\aheaders\x12\x14\n" +
+	"\x05topic\x18\x06 \x01(\tR\x05topic\x12\x10\n" +
+	"\x03key\x18\a \x01(\tR\x03key\x1a:\n" +
+	"\fHeadersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"_\n" +
+	"\x14PublishEventResponse\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12\x14\n" +
+	"\x05topic\x18\x02 \x01(\tR\x05topic\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\"S\n" +
+	"\x18PublishEventBatchRequest\x127\n" +
+	"\x06events\x18\x01 \x03(\v2\x1f.xform.eventbus.v1.EventRequestR\x06events\"\x82\x01\n" +
+	"\vEventResult\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x05R\x05index\x12\x19\n" +
+	"\bevent_id\x18\x02 \x01(\tR\aeventId\x12\x14\n" +
+	"\x05topic\x18\x03 \x01(\tR\x05topic\x12\x16\n" +
+	"\x06status\x18\x04 \x01(\tR\x06status\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\"\xca\x01\n" +
+	"\x19PublishEventBatchResponse\x12!\n" +
+	"\ftotal_events\x18\x01 \x01(\x05R\vtotalEvents\x12+\n" +
+	"\x11successful_events\x18\x02 \x01(\x05R\x10successfulEvents\x12#\n" +
+	"\rfailed_events\x18\x03 \x01(\x05R\ffailedEvents\x128\n" +
+	"\aresults\x18\x04 \x03(\v2\x1e.xform.eventbus.v1.EventResultR\aresults2\xd4\x01\n" +
+	"\bEventBus\x12X\n" +
+	"\fPublishEvent\x12\x1f.xform.eventbus.v1.EventRequest\x1a'.xform.eventbus.v1.PublishEventResponse\x12n\n" +
+	"\x11PublishEventBatch\x12+.xform.eventbus.v1.PublishEventBatchRequest\x1a,.xform.eventbus.v1.PublishEventBatchResponseBHZFgithub.com/Mir00r/X-Form-Backend/shared/eventbus/eventbusv1;eventbusv1b\x06proto3"
+
+var (
+	file_eventbus_v1_eventbus_proto_rawDescOnce sync.Once
+	file_eventbus_v1_eventbus_proto_rawDescData []byte
+)
+
+func file_eventbus_v1_eventbus_proto_rawDescGZIP() []byte {
+	file_eventbus_v1_eventbus_proto_rawDescOnce.Do(func() {
+		file_eventbus_v1_eventbus_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_eventbus_v1_eventbus_proto_rawDesc), len(file_eventbus_v1_eventbus_proto_rawDesc)))
+	})
+	return file_eventbus_v1_eventbus_proto_rawDescData
+}
+
+var file_eventbus_v1_eventbus_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_eventbus_v1_eventbus_proto_goTypes = []any{
+	(*EventRequest)(nil),              // 0: xform.eventbus.v1.EventRequest
+	(*PublishEventResponse)(nil),      // 1: xform.eventbus.v1.PublishEventResponse
+	(*PublishEventBatchRequest)(nil),  // 2: xform.eventbus.v1.PublishEventBatchRequest
+	(*EventResult)(nil),               // 3: xform.eventbus.v1.EventResult
+	(*PublishEventBatchResponse)(nil), // 4: xform.eventbus.v1.PublishEventBatchResponse
+	nil,                               // 5: xform.eventbus.v1.EventRequest.HeadersEntry
+	(*structpb.Struct)(nil),           // 6: google.protobuf.Struct
+}
+var file_eventbus_v1_eventbus_proto_depIdxs = []int32{
+	6, // 0: xform.eventbus.v1.EventRequest.data:type_name -> google.protobuf.Struct
+	5, // 1: xform.eventbus.v1.EventRequest.headers:type_name -> xform.eventbus.v1.EventRequest.HeadersEntry
+	0, // 2: xform.eventbus.v1.PublishEventBatchRequest.events:type_name -> xform.eventbus.v1.EventRequest
+	3, // 3: xform.eventbus.v1.PublishEventBatchResponse.results:type_name -> xform.eventbus.v1.EventResult
+	0, // 4: xform.eventbus.v1.EventBus.PublishEvent:input_type -> xform.eventbus.v1.EventRequest
+	2, // 5: xform.eventbus.v1.EventBus.PublishEventBatch:input_type -> xform.eventbus.v1.PublishEventBatchRequest
+	1, // 6: xform.eventbus.v1.EventBus.PublishEvent:output_type -> xform.eventbus.v1.PublishEventResponse
+	4, // 7: xform.eventbus.v1.EventBus.PublishEventBatch:output_type -> xform.eventbus.v1.PublishEventBatchResponse
+	6, // [6:8] is the sub-list for method output_type
+	4, // [4:6] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_eventbus_v1_eventbus_proto_init() }
+func file_eventbus_v1_eventbus_proto_init() {
+	if File_eventbus_v1_eventbus_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_eventbus_v1_eventbus_proto_rawDesc), len(file_eventbus_v1_eventbus_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_eventbus_v1_eventbus_proto_goTypes,
+		DependencyIndexes: file_eventbus_v1_eventbus_proto_depIdxs,
+		MessageInfos:      file_eventbus_v1_eventbus_proto_msgTypes,
+	}.Build()
+	File_eventbus_v1_eventbus_proto = out.File
+	file_eventbus_v1_eventbus_proto_goTypes = nil
+	file_eventbus_v1_eventbus_proto_depIdxs = nil
+}
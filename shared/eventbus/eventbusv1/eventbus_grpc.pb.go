@@ -0,0 +1,171 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: eventbus/v1/eventbus.proto
+
+package eventbusv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	EventBus_PublishEvent_FullMethodName      = "/xform.eventbus.v1.EventBus/PublishEvent"
+	EventBus_PublishEventBatch_FullMethodName = "/xform.eventbus.v1.EventBus/PublishEventBatch"
+)
+
+// EventBusClient is the client API for EventBus service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// EventBus publishes application events to Kafka. It mirrors the
+// POST /events and POST /events/batch HTTP endpoints.
+type EventBusClient interface {
+	// PublishEvent publishes a single event
+	PublishEvent(ctx context.Context, in *EventRequest, opts ...grpc.CallOption) (*PublishEventResponse, error)
+	// PublishEventBatch publishes up to 1000 events. Events are validated and
+	// published individually, so one bad event doesn't fail the batch.
+	PublishEventBatch(ctx context.Context, in *PublishEventBatchRequest, opts ...grpc.CallOption) (*PublishEventBatchResponse, error)
+}
+
+type eventBusClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEventBusClient(cc grpc.ClientConnInterface) EventBusClient {
+	return &eventBusClient{cc}
+}
+
+func (c *eventBusClient) PublishEvent(ctx context.Context, in *EventRequest, opts ...grpc.CallOption) (*PublishEventResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PublishEventResponse)
+	err := c.cc.Invoke(ctx, EventBus_PublishEvent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventBusClient) PublishEventBatch(ctx context.Context, in *PublishEventBatchRequest, opts ...grpc.CallOption) (*PublishEventBatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PublishEventBatchResponse)
+	err := c.cc.Invoke(ctx, EventBus_PublishEventBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// EventBusServer is the server API for EventBus service.
+// All implementations must embed UnimplementedEventBusServer
+// for forward compatibility.
+//
+// EventBus publishes application events to Kafka. It mirrors the
+// POST /events and POST /events/batch HTTP endpoints.
+type EventBusServer interface {
+	// PublishEvent publishes a single event
+	PublishEvent(context.Context, *EventRequest) (*PublishEventResponse, error)
+	// PublishEventBatch publishes up to 1000 events. Events are validated and
+	// published individually, so one bad event doesn't fail the batch.
+	PublishEventBatch(context.Context, *PublishEventBatchRequest) (*PublishEventBatchResponse, error)
+	mustEmbedUnimplementedEventBusServer()
+}
+
+// UnimplementedEventBusServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedEventBusServer struct{}
+
+func (UnimplementedEventBusServer) PublishEvent(context.Context, *EventRequest) (*PublishEventResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PublishEvent not implemented")
+}
+func (UnimplementedEventBusServer) PublishEventBatch(context.Context, *PublishEventBatchRequest) (*PublishEventBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PublishEventBatch not implemented")
+}
+func (UnimplementedEventBusServer) mustEmbedUnimplementedEventBusServer() {}
+func (UnimplementedEventBusServer) testEmbeddedByValue()                  {}
+
+// UnsafeEventBusServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to EventBusServer will
+// result in compilation errors.
+type UnsafeEventBusServer interface {
+	mustEmbedUnimplementedEventBusServer()
+}
+
+func RegisterEventBusServer(s grpc.ServiceRegistrar, srv EventBusServer) {
+	// If the following call pancis, it indicates UnimplementedEventBusServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&EventBus_ServiceDesc, srv)
+}
+
+func _EventBus_PublishEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventBusServer).PublishEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventBus_PublishEvent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventBusServer).PublishEvent(ctx, req.(*EventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventBus_PublishEventBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PublishEventBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventBusServer).PublishEventBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventBus_PublishEventBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventBusServer).PublishEventBatch(ctx, req.(*PublishEventBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// EventBus_ServiceDesc is the grpc.ServiceDesc for EventBus service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var EventBus_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "xform.eventbus.v1.EventBus",
+	HandlerType: (*EventBusServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "PublishEvent",
+			Handler:    _EventBus_PublishEvent_Handler,
+		},
+		{
+			MethodName: "PublishEventBatch",
+			Handler:    _EventBus_PublishEventBatch_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "eventbus/v1/eventbus.proto",
+}
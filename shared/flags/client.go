@@ -0,0 +1,152 @@
+package flags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNotFound is returned for flags that are not defined
+var ErrNotFound = errors.New("flag not found")
+
+// Store keeps the flags changed at runtime, shared by every service
+type Store interface {
+	// Load returns the stored flags by name
+	Load(ctx context.Context) (map[string]Flag, error)
+	// Save stores the flag, replacing the flag of the same name
+	Save(ctx context.Context, flag Flag) error
+}
+
+// Client evaluates flags against a snapshot of the defined and stored
+// flags. It is safe for concurrent use.
+type Client struct {
+	store    Store
+	defined  []Flag
+	snapshot atomic.Pointer[map[string]Flag]
+	now      func() time.Time
+}
+
+// NewClient creates a client evaluating the defined flags, overridden by
+// those in store, which may be nil for defined flags only. Until the first
+// Refresh only the defined flags are evaluated.
+func NewClient(store Store, defined []Flag) *Client {
+	c := &Client{store: store, defined: defined, now: time.Now}
+	flags := make(map[string]Flag, len(defined))
+	for _, flag := range defined {
+		flags[flag.Name] = flag
+	}
+	c.snapshot.Store(&flags)
+	return c
+}
+
+// IsEnabled reports whether the flag is on for the subject of ctx. Flags
+// that are not defined are off.
+func (c *Client) IsEnabled(ctx context.Context, name string) bool {
+	flag, ok := (*c.snapshot.Load())[name]
+	return ok && flag.EnabledFor(SubjectFrom(ctx))
+}
+
+// Flags returns the flags of the snapshot by name
+func (c *Client) Flags() []Flag {
+	snapshot := *c.snapshot.Load()
+	flags := make([]Flag, 0, len(snapshot))
+	for _, flag := range snapshot {
+		flags = append(flags, flag)
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+	return flags
+}
+
+// Get returns a flag of the snapshot
+func (c *Client) Get(name string) (Flag, error) {
+	flag, ok := (*c.snapshot.Load())[name]
+	if !ok {
+		return Flag{}, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return flag, nil
+}
+
+// Refresh reloads the stored flags into the snapshot. The snapshot is kept
+// when the store fails.
+func (c *Client) Refresh(ctx context.Context) error {
+	if c.store == nil {
+		return nil
+	}
+	stored, err := c.store.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load flags: %w", err)
+	}
+
+	flags := make(map[string]Flag, len(c.defined)+len(stored))
+	for _, flag := range c.defined {
+		flags[flag.Name] = flag
+	}
+	for name, flag := range stored {
+		flags[name] = flag
+	}
+	c.snapshot.Store(&flags)
+	return nil
+}
+
+// Run refreshes the snapshot every interval until ctx is done, passing the
+// failures to onError
+func (c *Client) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.Refresh(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// Set validates and stores the flag on behalf of actor, returning the flag
+// it replaces, nil for a new flag. Other services see the change on their
+// next refresh.
+func (c *Client) Set(ctx context.Context, flag Flag, actor string) (*Flag, error) {
+	if c.store == nil {
+		return nil, errors.New("flags are read-only without a store")
+	}
+	if err := flag.Validate(); err != nil {
+		return nil, err
+	}
+	if flag.By == "" {
+		flag.By = ByUser
+	}
+	flag.UpdatedAt = c.now().UTC()
+	flag.UpdatedBy = actor
+
+	var previous *Flag
+	if existing, err := c.Get(flag.Name); err == nil {
+		previous = &existing
+	}
+	if err := c.store.Save(ctx, flag); err != nil {
+		return nil, fmt.Errorf("failed to save flag: %w", err)
+	}
+	if err := c.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return previous, nil
+}
+
+var defaultClient atomic.Pointer[Client]
+
+// SetDefault makes c the client of IsEnabled
+func SetDefault(c *Client) {
+	defaultClient.Store(c)
+}
+
+// IsEnabled reports whether the flag is on for the subject of ctx with the
+// default client. Every flag is off until SetDefault is called.
+func IsEnabled(ctx context.Context, name string) bool {
+	c := defaultClient.Load()
+	return c != nil && c.IsEnabled(ctx, name)
+}
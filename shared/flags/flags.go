@@ -0,0 +1,147 @@
+// Package flags evaluates feature flags for gradual rollouts. Flags are
+// defined in configuration and overridden at runtime in a Redis hash shared
+// by every service; each service evaluates them against a local snapshot it
+// refreshes every few seconds, so checking a flag never leaves the process.
+//
+// A flag is on for a subject when it is enabled and either the subject's
+// organization is allowlisted or the subject falls in the rollout
+// percentage. Subjects are bucketed by a stable hash of the flag name and
+// their user or organization ID, so a subject gets the same answer on every
+// request and in every service, and raising the percentage only adds
+// subjects.
+package flags
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"time"
+)
+
+// Buckets is the number of buckets subjects are hashed into; a percentage
+// of P turns a flag on for the subjects in the first P*Buckets/100
+const Buckets = 10000
+
+// Subjects are bucketed by their user ID, or their organization ID so
+// every member of an organization gets the same answer
+const (
+	ByUser         = "user"
+	ByOrganization = "organization"
+)
+
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9_.-]{0,63}$`)
+
+// Flag is a feature flag and its rollout rules
+type Flag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Enabled turns the flag off for everyone when false, whatever the
+	// other rules
+	Enabled bool `json:"enabled"`
+	// Percentage of subjects the flag is on for, from 0 to 100
+	Percentage int `json:"percentage"`
+	// By is what subjects are bucketed by, ByUser unless set
+	By string `json:"by,omitempty"`
+	// AllowedOrganizations always get the flag while it is enabled
+	AllowedOrganizations []string  `json:"allowed_organizations,omitempty"`
+	UpdatedAt            time.Time `json:"updated_at,omitempty"`
+	UpdatedBy            string    `json:"updated_by,omitempty"`
+}
+
+// Validate checks the rules of the flag
+func (f *Flag) Validate() error {
+	if !namePattern.MatchString(f.Name) {
+		return fmt.Errorf("flag name %q must be lowercase letters, digits, '_', '.' or '-', starting with a letter", f.Name)
+	}
+	if f.Percentage < 0 || f.Percentage > 100 {
+		return fmt.Errorf("flag %s: percentage must be from 0 to 100", f.Name)
+	}
+	if f.By != "" && f.By != ByUser && f.By != ByOrganization {
+		return fmt.Errorf("flag %s: by must be %s or %s", f.Name, ByUser, ByOrganization)
+	}
+	return nil
+}
+
+// EnabledFor reports whether the flag is on for the subject
+func (f *Flag) EnabledFor(subject Subject) bool {
+	if !f.Enabled {
+		return false
+	}
+	if subject.OrganizationID != "" {
+		for _, orgID := range f.AllowedOrganizations {
+			if orgID == subject.OrganizationID {
+				return true
+			}
+		}
+	}
+	if f.Percentage >= 100 {
+		return true
+	}
+	if f.Percentage <= 0 {
+		return false
+	}
+
+	key := subject.UserID
+	if f.By == ByOrganization {
+		key = subject.OrganizationID
+	}
+	if key == "" {
+		// Anonymous subjects only get flags rolled out to everyone
+		return false
+	}
+	return Bucket(f.Name, key) < f.Percentage*Buckets/100
+}
+
+// Bucket hashes the subject key into one of Buckets buckets. The flag name
+// is part of the hash, so the subjects of different flags are independent.
+func Bucket(flag, key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(flag))
+	h.Write([]byte{0})
+	h.Write([]byte(key))
+	return int(h.Sum32() % Buckets)
+}
+
+// Parse reads flags defined in configuration, a JSON array of flags
+func Parse(data string) ([]Flag, error) {
+	if data == "" {
+		return nil, nil
+	}
+	var defined []Flag
+	if err := json.Unmarshal([]byte(data), &defined); err != nil {
+		return nil, fmt.Errorf("flags must be a JSON array of flags: %w", err)
+	}
+	seen := make(map[string]bool, len(defined))
+	for i := range defined {
+		if err := defined[i].Validate(); err != nil {
+			return nil, err
+		}
+		if seen[defined[i].Name] {
+			return nil, fmt.Errorf("flag %s is defined twice", defined[i].Name)
+		}
+		seen[defined[i].Name] = true
+	}
+	return defined, nil
+}
+
+// Subject is who a flag is evaluated for
+type Subject struct {
+	UserID         string
+	OrganizationID string
+}
+
+type subjectKey struct{}
+
+// WithSubject returns a context evaluating flags for subject
+func WithSubject(ctx context.Context, subject Subject) context.Context {
+	return context.WithValue(ctx, subjectKey{}, subject)
+}
+
+// SubjectFrom returns the subject flags are evaluated for in ctx, empty
+// for anonymous requests
+func SubjectFrom(ctx context.Context) Subject {
+	subject, _ := ctx.Value(subjectKey{}).(Subject)
+	return subject
+}
@@ -0,0 +1,200 @@
+package flags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestBucketIsStable(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("user-%d", i)
+		b := Bucket("new_validator", key)
+		if b < 0 || b >= Buckets {
+			t.Fatalf("Bucket(%q) = %d, want [0, %d)", key, b, Buckets)
+		}
+		if again := Bucket("new_validator", key); again != b {
+			t.Fatalf("Bucket(%q) = %d then %d, want the same bucket", key, b, again)
+		}
+	}
+}
+
+func TestPercentageRollout(t *testing.T) {
+	const subjects = 20000
+	for _, percentage := range []int{1, 10, 25, 50, 90} {
+		flag := Flag{Name: "new_validator", Enabled: true, Percentage: percentage}
+		on := 0
+		for i := 0; i < subjects; i++ {
+			if flag.EnabledFor(Subject{UserID: fmt.Sprintf("user-%d", i)}) {
+				on++
+			}
+		}
+		got := float64(on) * 100 / subjects
+		if math.Abs(got-float64(percentage)) > 1 {
+			t.Errorf("%d%% rollout turned the flag on for %.2f%% of subjects", percentage, got)
+		}
+	}
+}
+
+func TestRaisingPercentageOnlyAddsSubjects(t *testing.T) {
+	low := Flag{Name: "new_analytics", Enabled: true, Percentage: 10}
+	high := low
+	high.Percentage = 30
+	for i := 0; i < 5000; i++ {
+		subject := Subject{UserID: fmt.Sprintf("user-%d", i)}
+		if low.EnabledFor(subject) && !high.EnabledFor(subject) {
+			t.Fatalf("%s had the flag at 10%% but not at 30%%", subject.UserID)
+		}
+	}
+}
+
+func TestFlagsBucketIndependently(t *testing.T) {
+	// At 50% each, about a quarter of subjects have both flags if their
+	// populations are independent, and half if they were the same
+	a := Flag{Name: "flag_a", Enabled: true, Percentage: 50}
+	b := Flag{Name: "flag_b", Enabled: true, Percentage: 50}
+	both := 0
+	for i := 0; i < 20000; i++ {
+		subject := Subject{UserID: fmt.Sprintf("user-%d", i)}
+		if a.EnabledFor(subject) && b.EnabledFor(subject) {
+			both++
+		}
+	}
+	if got := float64(both) / 20000; math.Abs(got-0.25) > 0.02 {
+		t.Errorf("%.3f of subjects have both flags, want about 0.25", got)
+	}
+}
+
+func TestEnabledFor(t *testing.T) {
+	alice := Subject{UserID: "alice", OrganizationID: "org-1"}
+	anonymous := Subject{}
+	for _, tc := range []struct {
+		name    string
+		flag    Flag
+		subject Subject
+		want    bool
+	}{
+		{"disabled", Flag{Name: "f", Percentage: 100, AllowedOrganizations: []string{"org-1"}}, alice, false},
+		{"everyone", Flag{Name: "f", Enabled: true, Percentage: 100}, anonymous, true},
+		{"nobody", Flag{Name: "f", Enabled: true}, alice, false},
+		{"allowlisted", Flag{Name: "f", Enabled: true, AllowedOrganizations: []string{"org-1"}}, alice, true},
+		{"not allowlisted", Flag{Name: "f", Enabled: true, AllowedOrganizations: []string{"org-2"}}, alice, false},
+		{"anonymous in a rollout", Flag{Name: "f", Enabled: true, Percentage: 99}, anonymous, false},
+		{"by organization without one", Flag{Name: "f", Enabled: true, Percentage: 99, By: ByOrganization}, Subject{UserID: "alice"}, false},
+	} {
+		if got := tc.flag.EnabledFor(tc.subject); got != tc.want {
+			t.Errorf("%s: EnabledFor = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	// Bucketing by organization gives every member the same answer
+	flag := Flag{Name: "org_rollout", Enabled: true, Percentage: 50, By: ByOrganization}
+	for i := 0; i < 100; i++ {
+		org := fmt.Sprintf("org-%d", i)
+		want := flag.EnabledFor(Subject{UserID: "first", OrganizationID: org})
+		if got := flag.EnabledFor(Subject{UserID: "second", OrganizationID: org}); got != want {
+			t.Fatalf("members of %s get %v and %v", org, want, got)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	defined, err := Parse(`[{"name":"new_validator","enabled":true,"percentage":10}]`)
+	if err != nil || len(defined) != 1 || defined[0].Percentage != 10 {
+		t.Fatalf("Parse = %+v, %v", defined, err)
+	}
+	for _, data := range []string{
+		`{"name":"x"}`,
+		`[{"name":"New Validator"}]`,
+		`[{"name":"f","percentage":101}]`,
+		`[{"name":"f","by":"session"}]`,
+		`[{"name":"f"},{"name":"f"}]`,
+	} {
+		if _, err := Parse(data); err == nil {
+			t.Errorf("Parse(%s) succeeded, want an error", data)
+		}
+	}
+}
+
+// memoryStore keeps flags in a map
+type memoryStore struct {
+	flags map[string]Flag
+	err   error
+}
+
+func (s *memoryStore) Load(context.Context) (map[string]Flag, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	flags := make(map[string]Flag, len(s.flags))
+	for name, flag := range s.flags {
+		flags[name] = flag
+	}
+	return flags, nil
+}
+
+func (s *memoryStore) Save(_ context.Context, flag Flag) error {
+	s.flags[flag.Name] = flag
+	return nil
+}
+
+func TestClient(t *testing.T) {
+	ctx := WithSubject(context.Background(), Subject{UserID: "alice"})
+	store := &memoryStore{flags: map[string]Flag{}}
+	client := NewClient(store, []Flag{{Name: "new_validator", Enabled: true, Percentage: 100}})
+
+	if !client.IsEnabled(ctx, "new_validator") || client.IsEnabled(ctx, "undefined") {
+		t.Fatal("expected defined flags to be evaluated before the first refresh")
+	}
+
+	// Another replica turns the flag off through the store
+	store.flags["new_validator"] = Flag{Name: "new_validator"}
+	if err := client.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if client.IsEnabled(ctx, "new_validator") {
+		t.Fatal("expected the stored flag to override the defined one")
+	}
+
+	store.err = errors.New("redis down")
+	if err := client.Refresh(ctx); err == nil {
+		t.Fatal("expected the refresh to fail")
+	}
+	if _, err := client.Get("new_validator"); err != nil {
+		t.Fatal("expected the snapshot to be kept when the store fails")
+	}
+	store.err = nil
+
+	previous, err := client.Set(ctx, Flag{Name: "new_analytics", Enabled: true, Percentage: 100}, "admin")
+	if err != nil || previous != nil {
+		t.Fatalf("Set = %v, %v, want a new flag", previous, err)
+	}
+	if !client.IsEnabled(ctx, "new_analytics") {
+		t.Fatal("expected the flag set to be evaluated at once")
+	}
+	previous, err = client.Set(ctx, Flag{Name: "new_analytics"}, "admin")
+	if err != nil || previous == nil || !previous.Enabled {
+		t.Fatalf("Set = %+v, %v, want the flag replaced", previous, err)
+	}
+	if stored := store.flags["new_analytics"]; stored.UpdatedBy != "admin" || stored.UpdatedAt.IsZero() || stored.By != ByUser {
+		t.Errorf("stored %+v, want who changed it and when", stored)
+	}
+	if _, err := client.Set(ctx, Flag{Name: "f", Percentage: 200}, "admin"); err == nil {
+		t.Error("expected an invalid flag to be refused")
+	}
+}
+
+func TestDefaultClient(t *testing.T) {
+	ctx := context.Background()
+	SetDefault(nil)
+	if IsEnabled(ctx, "new_validator") {
+		t.Fatal("expected flags to be off without a default client")
+	}
+	SetDefault(NewClient(nil, []Flag{{Name: "new_validator", Enabled: true, Percentage: 100}}))
+	defer SetDefault(nil)
+	if !IsEnabled(ctx, "new_validator") {
+		t.Fatal("expected the default client to be used")
+	}
+}
@@ -0,0 +1,53 @@
+package flags
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultRedisKey is the hash the flags are stored in
+const DefaultRedisKey = "feature_flags"
+
+// RedisStore keeps flags in a Redis hash, a JSON flag per field
+type RedisStore struct {
+	client redis.UniversalClient
+	key    string
+}
+
+// NewRedisStore creates a store of flags in the hash key
+func NewRedisStore(client redis.UniversalClient, key string) *RedisStore {
+	if key == "" {
+		key = DefaultRedisKey
+	}
+	return &RedisStore{client: client, key: key}
+}
+
+// Load returns the stored flags by name. Fields that do not hold a valid
+// flag are skipped, so one bad write doesn't turn every flag off.
+func (s *RedisStore) Load(ctx context.Context) (map[string]Flag, error) {
+	fields, err := s.client.HGetAll(ctx, s.key).Result()
+	if err != nil {
+		return nil, err
+	}
+	flags := make(map[string]Flag, len(fields))
+	for name, data := range fields {
+		var flag Flag
+		if err := json.Unmarshal([]byte(data), &flag); err != nil || flag.Name != name || flag.Validate() != nil {
+			continue
+		}
+		flags[name] = flag
+	}
+	return flags, nil
+}
+
+// Save stores the flag
+func (s *RedisStore) Save(ctx context.Context, flag Flag) error {
+	data, err := json.Marshal(flag)
+	if err != nil {
+		return fmt.Errorf("failed to encode flag: %w", err)
+	}
+	return s.client.HSet(ctx, s.key, flag.Name, data).Err()
+}
@@ -1,6 +1,7 @@
 package observability
 
 import (
+	"errors"
 	"fmt"
 	"runtime/debug"
 	"time"
@@ -70,14 +71,10 @@ func NewErrorProvider(config ErrorConfig, logger *zap.Logger) (*ErrorProvider, e
 		}
 
 		logger.Info("Sentry error tracking initialized",
-			zap.String("service", config.ServiceName),
-			zap.String("environment", config.Environment),
 			zap.Float64("sample_rate", config.SampleRate),
 		)
 	} else {
-		logger.Info("Error tracking initialized without Sentry",
-			zap.String("service", config.ServiceName),
-		)
+		logger.Info("Error tracking initialized without Sentry")
 	}
 
 	return ep, nil
@@ -122,16 +119,12 @@ func (ep *ErrorProvider) CapturePanic(context ErrorContext) {
 		ep.logger.Error("Panic recovered",
 			zap.Any("panic", r),
 			zap.String("stack_trace", stackTrace),
-			zap.String("service", ep.serviceName),
 			zap.Any("context", context),
 		)
 
 		// Send to Sentry if enabled
 		if ep.sentryEnabled {
-			sentry.WithScope(func(scope *sentry.Scope) {
-				ep.setSentryScope(scope, context)
-				sentry.CaptureException(fmt.Errorf("panic: %v", r))
-			})
+			ep.scopedHub(context).CaptureException(fmt.Errorf("panic: %v", r))
 		}
 
 		// Re-panic to maintain normal panic behavior
@@ -207,8 +200,6 @@ func (ep *ErrorProvider) Flush(timeout time.Duration) bool {
 func (ep *ErrorProvider) logError(err error, context ErrorContext) {
 	fields := []zap.Field{
 		zap.Error(err),
-		zap.String("service", ep.serviceName),
-		zap.String("environment", ep.environment),
 	}
 
 	if context.UserID != "" {
@@ -249,32 +240,26 @@ func (ep *ErrorProvider) logError(err error, context ErrorContext) {
 func (ep *ErrorProvider) logException(message string, context ErrorContext) {
 	ep.logger.Error("Exception captured",
 		zap.String("message", message),
-		zap.String("service", ep.serviceName),
 		zap.Any("context", context),
 	)
 }
 
 // sendToSentry sends error to Sentry
 func (ep *ErrorProvider) sendToSentry(err error, context ErrorContext) string {
-	return string(sentry.WithScope(func(scope *sentry.Scope) {
-		ep.setSentryScope(scope, context)
-		sentry.CaptureException(err)
-	}))
+	hub := ep.scopedHub(context)
+	return eventID(hub.CaptureException(err))
 }
 
 // sendExceptionToSentry sends exception to Sentry
 func (ep *ErrorProvider) sendExceptionToSentry(message string, context ErrorContext) string {
-	return string(sentry.WithScope(func(scope *sentry.Scope) {
-		ep.setSentryScope(scope, context)
-		sentry.CaptureException(fmt.Errorf(message))
-	}))
+	hub := ep.scopedHub(context)
+	return eventID(hub.CaptureException(errors.New(message)))
 }
 
 // sendMessageToSentry sends message to Sentry
 func (ep *ErrorProvider) sendMessageToSentry(message, level string, context ErrorContext) string {
-	return string(sentry.WithScope(func(scope *sentry.Scope) {
-		ep.setSentryScope(scope, context)
-
+	hub := ep.scopedHub(context)
+	hub.ConfigureScope(func(scope *sentry.Scope) {
 		var sentryLevel sentry.Level
 		switch level {
 		case "debug":
@@ -289,9 +274,27 @@ func (ep *ErrorProvider) sendMessageToSentry(message, level string, context Erro
 			sentryLevel = sentry.LevelInfo
 		}
 
-		sentry.CaptureMessage(message)
 		scope.SetLevel(sentryLevel)
-	}))
+	})
+	return eventID(hub.CaptureMessage(message))
+}
+
+// scopedHub clones the current hub with the scope of the context, so the
+// tags of one capture don't leak into the next
+func (ep *ErrorProvider) scopedHub(context ErrorContext) *sentry.Hub {
+	hub := sentry.CurrentHub().Clone()
+	hub.ConfigureScope(func(scope *sentry.Scope) {
+		ep.setSentryScope(scope, context)
+	})
+	return hub
+}
+
+// eventID returns the ID of a captured event, empty when it was dropped
+func eventID(id *sentry.EventID) string {
+	if id == nil {
+		return ""
+	}
+	return string(*id)
 }
 
 // setSentryScope sets Sentry scope with context
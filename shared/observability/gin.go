@@ -6,11 +6,19 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	oteltrace "go.opentelemetry.io/otel/trace"
 )
 
-// GinMiddleware returns Gin middleware for observability
+// UnmatchedRoute is the route label of the requests matching no route
+const UnmatchedRoute = "unmatched"
+
+// GinMiddleware returns Gin middleware for observability. It records the
+// http_server_* metrics by route template, continues the trace of the
+// caller from its traceparent header and reports server errors.
 func (p *Provider) GinMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
@@ -19,8 +27,10 @@ func (p *Provider) GinMiddleware() gin.HandlerFunc {
 		p.metrics.IncrementActiveConnections()
 		defer p.metrics.DecrementActiveConnections()
 
-		// Start tracing span
-		ctx, span := p.tracing.StartSpan(c.Request.Context(), fmt.Sprintf("HTTP %s %s", c.Request.Method, c.FullPath()))
+		// Start tracing span, in the trace of the caller if any
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		ctx, span := p.tracing.StartSpan(ctx, fmt.Sprintf("HTTP %s %s", c.Request.Method, c.FullPath()),
+			oteltrace.WithSpanKind(oteltrace.SpanKindServer))
 		defer span.End()
 
 		// Update request context with span
@@ -68,11 +78,12 @@ func (p *Provider) GinMiddleware() gin.HandlerFunc {
 		// Continue with request
 		c.Next()
 
-		// Record metrics after request completion
+		// Record metrics after request completion; requests matching no
+		// route share a label rather than one per path
 		duration := time.Since(start)
 		endpoint := c.FullPath()
 		if endpoint == "" {
-			endpoint = c.Request.URL.Path
+			endpoint = UnmatchedRoute
 		}
 		statusCode := c.Writer.Status()
 		statusCodeStr := strconv.Itoa(statusCode)
@@ -81,7 +92,6 @@ func (p *Provider) GinMiddleware() gin.HandlerFunc {
 			c.Request.Method,
 			endpoint,
 			statusCodeStr,
-			userID,
 			duration,
 			c.Request.ContentLength,
 			int64(c.Writer.Size()),
@@ -0,0 +1,113 @@
+package observability
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+)
+
+func newTestProvider(t *testing.T, serviceName string) *Provider {
+	t.Helper()
+	config := DefaultConfig(serviceName)
+	config.Environment = "test"
+	config.Version = "1.2.3"
+	config.Tracing.OTLPEndpoint = ""
+	config.Errors.EnableSentry = false
+	provider, err := New(config, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider
+}
+
+func TestGinMiddlewareMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	provider := newTestProvider(t, "form-service")
+	router := gin.New()
+	router.Use(provider.GinMiddleware())
+	var traceID interface{}
+	router.GET("/api/v1/forms/:id", func(c *gin.Context) {
+		traceID, _ = c.Get("trace_id")
+		c.Status(http.StatusOK)
+	})
+
+	// The trace of the caller continues here
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/forms/f-1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/forms/f-2", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing/1", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing/2", nil))
+
+	if traceID == "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("a later request kept the trace of the first")
+	}
+
+	want := `
+# HELP http_server_requests_total Total number of HTTP requests served
+# TYPE http_server_requests_total counter
+http_server_requests_total{environment="test",method="GET",route="/api/v1/forms/:id",service="form-service",status_code="200",version="1.2.3"} 2
+http_server_requests_total{environment="test",method="GET",route="unmatched",service="form-service",status_code="404",version="1.2.3"} 2
+`
+	if err := testutil.GatherAndCompare(provider.Metrics().Registry(), strings.NewReader(want), "http_server_requests_total"); err != nil {
+		t.Error(err)
+	}
+	count, err := testutil.GatherAndCount(provider.Metrics().Registry(), "http_server_request_duration_seconds")
+	if err != nil || count != 2 {
+		t.Errorf("http_server_request_duration_seconds has %d series (%v), want one per route", count, err)
+	}
+}
+
+func TestGinMiddlewareContinuesTrace(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	provider := newTestProvider(t, "form-service")
+	router := gin.New()
+	router.Use(provider.GinMiddleware())
+	var traceID interface{}
+	router.GET("/ping", func(c *gin.Context) {
+		traceID, _ = c.Get("trace_id")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	if traceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace ID = %v, want the caller's", traceID)
+	}
+}
+
+func TestServiceMetrics(t *testing.T) {
+	provider := newTestProvider(t, "form-service")
+	submissions := provider.Metrics().NewCounterVec("form_submissions_total", "Form submissions", "outcome")
+	submissions.WithLabelValues("accepted").Inc()
+
+	want := `
+# HELP xform_form_submissions_total Form submissions
+# TYPE xform_form_submissions_total counter
+xform_form_submissions_total{environment="test",outcome="accepted",service="form-service",version="1.2.3"} 1
+`
+	if err := testutil.GatherAndCompare(provider.Metrics().Registry(), strings.NewReader(want), "xform_form_submissions_total"); err != nil {
+		t.Error(err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a metric twice did not panic")
+		}
+	}()
+	provider.Metrics().NewCounterVec("form_submissions_total", "Form submissions", "outcome")
+}
+
+func TestParseHeaders(t *testing.T) {
+	got := parseHeaders("api-key=secret, x-tenant = a%20b ,broken,=empty")
+	want := map[string]string{"api-key": "secret", "x-tenant": "a b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseHeaders = %v, want %v", got, want)
+	}
+}
@@ -5,11 +5,14 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
 )
 
-// MetricsProvider handles Prometheus metrics collection
+// MetricsProvider handles Prometheus metrics collection. The HTTP server
+// metrics are named alike in every service, without namespace, and told
+// apart by their service label; the other metrics are in the namespace.
 type MetricsProvider struct {
 	// HTTP Metrics
 	httpRequestsTotal     prometheus.CounterVec
@@ -38,9 +41,11 @@ type MetricsProvider struct {
 	businessMetrics prometheus.CounterVec
 	businessGauges  prometheus.GaugeVec
 
-	registry    prometheus.Registry
-	serviceName string
-	logger      *zap.Logger
+	registry     *prometheus.Registry
+	namespace    string
+	commonLabels prometheus.Labels
+	serviceName  string
+	logger       *zap.Logger
 }
 
 // MetricsConfig holds configuration for metrics
@@ -63,61 +68,59 @@ func NewMetricsProvider(config MetricsConfig, logger *zap.Logger) *MetricsProvid
 	}
 
 	mp := &MetricsProvider{
-		registry:    *registry,
-		serviceName: config.ServiceName,
-		logger:      logger,
-
-		// HTTP Metrics
+		registry:     registry,
+		namespace:    config.Namespace,
+		commonLabels: commonLabels,
+		serviceName:  config.ServiceName,
+		logger:       logger,
+
+		// HTTP Metrics, labelled by route template rather than path so
+		// their cardinality stays bounded
 		httpRequestsTotal: *prometheus.NewCounterVec(
 			prometheus.CounterOpts{
-				Namespace:   config.Namespace,
-				Name:        "http_requests_total",
-				Help:        "Total number of HTTP requests",
+				Name:        "http_server_requests_total",
+				Help:        "Total number of HTTP requests served",
 				ConstLabels: commonLabels,
 			},
-			[]string{"method", "endpoint", "status_code", "user_id"},
+			[]string{"method", "route", "status_code"},
 		),
 
 		httpRequestDuration: *prometheus.NewHistogramVec(
 			prometheus.HistogramOpts{
-				Namespace:   config.Namespace,
-				Name:        "http_request_duration_seconds",
+				Name:        "http_server_request_duration_seconds",
 				Help:        "HTTP request duration in seconds",
 				ConstLabels: commonLabels,
 				Buckets:     prometheus.DefBuckets,
 			},
-			[]string{"method", "endpoint", "status_code"},
+			[]string{"method", "route", "status_code"},
 		),
 
 		httpActiveConnections: prometheus.NewGauge(
 			prometheus.GaugeOpts{
-				Namespace:   config.Namespace,
-				Name:        "http_active_connections",
-				Help:        "Number of active HTTP connections",
+				Name:        "http_server_active_requests",
+				Help:        "Number of HTTP requests being served",
 				ConstLabels: commonLabels,
 			},
 		),
 
 		httpRequestSize: *prometheus.NewHistogramVec(
 			prometheus.HistogramOpts{
-				Namespace:   config.Namespace,
-				Name:        "http_request_size_bytes",
+				Name:        "http_server_request_size_bytes",
 				Help:        "HTTP request size in bytes",
 				ConstLabels: commonLabels,
 				Buckets:     prometheus.ExponentialBuckets(100, 10, 7),
 			},
-			[]string{"method", "endpoint"},
+			[]string{"method", "route"},
 		),
 
 		httpResponseSize: *prometheus.NewHistogramVec(
 			prometheus.HistogramOpts{
-				Namespace:   config.Namespace,
-				Name:        "http_response_size_bytes",
+				Name:        "http_server_response_size_bytes",
 				Help:        "HTTP response size in bytes",
 				ConstLabels: commonLabels,
 				Buckets:     prometheus.ExponentialBuckets(100, 10, 7),
 			},
-			[]string{"method", "endpoint", "status_code"},
+			[]string{"method", "route", "status_code"},
 		),
 
 		// Service Metrics
@@ -207,7 +210,7 @@ func NewMetricsProvider(config MetricsConfig, logger *zap.Logger) *MetricsProvid
 				Help:        "Total number of external service calls",
 				ConstLabels: commonLabels,
 			},
-			[]string{"service", "method", "endpoint", "status_code"},
+			[]string{"target_service", "method", "endpoint", "status_code"},
 		),
 
 		externalServiceDuration: *prometheus.NewHistogramVec(
@@ -218,7 +221,7 @@ func NewMetricsProvider(config MetricsConfig, logger *zap.Logger) *MetricsProvid
 				ConstLabels: commonLabels,
 				Buckets:     prometheus.DefBuckets,
 			},
-			[]string{"service", "method", "endpoint"},
+			[]string{"target_service", "method", "endpoint"},
 		),
 
 		// Business Metrics
@@ -243,8 +246,10 @@ func NewMetricsProvider(config MetricsConfig, logger *zap.Logger) *MetricsProvid
 		),
 	}
 
-	// Register all metrics
+	// Register all metrics, with the runtime metrics of the process
 	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
 		&mp.httpRequestsTotal,
 		&mp.httpRequestDuration,
 		mp.httpActiveConnections,
@@ -268,7 +273,6 @@ func NewMetricsProvider(config MetricsConfig, logger *zap.Logger) *MetricsProvid
 	go mp.trackUptime()
 
 	logger.Info("Metrics provider initialized",
-		zap.String("service", config.ServiceName),
 		zap.String("namespace", config.Namespace),
 	)
 
@@ -277,24 +281,73 @@ func NewMetricsProvider(config MetricsConfig, logger *zap.Logger) *MetricsProvid
 
 // HTTP Metrics Methods
 
-// RecordHTTPRequest records HTTP request metrics
-func (mp *MetricsProvider) RecordHTTPRequest(method, endpoint, statusCode, userID string, duration time.Duration, requestSize, responseSize int64) {
-	mp.httpRequestsTotal.WithLabelValues(method, endpoint, statusCode, userID).Inc()
-	mp.httpRequestDuration.WithLabelValues(method, endpoint, statusCode).Observe(duration.Seconds())
-	mp.httpRequestSize.WithLabelValues(method, endpoint).Observe(float64(requestSize))
-	mp.httpResponseSize.WithLabelValues(method, endpoint, statusCode).Observe(float64(responseSize))
+// RecordHTTPRequest records HTTP request metrics. route is the route
+// template, such as /api/v1/forms/:id.
+func (mp *MetricsProvider) RecordHTTPRequest(method, route, statusCode string, duration time.Duration, requestSize, responseSize int64) {
+	mp.httpRequestsTotal.WithLabelValues(method, route, statusCode).Inc()
+	mp.httpRequestDuration.WithLabelValues(method, route, statusCode).Observe(duration.Seconds())
+	mp.httpRequestSize.WithLabelValues(method, route).Observe(float64(requestSize))
+	mp.httpResponseSize.WithLabelValues(method, route, statusCode).Observe(float64(responseSize))
 }
 
-// IncrementActiveConnections increments active connections
+// IncrementActiveConnections increments the requests being served
 func (mp *MetricsProvider) IncrementActiveConnections() {
 	mp.httpActiveConnections.Inc()
 }
 
-// DecrementActiveConnections decrements active connections
+// DecrementActiveConnections decrements the requests being served
 func (mp *MetricsProvider) DecrementActiveConnections() {
 	mp.httpActiveConnections.Dec()
 }
 
+// Service-Specific Metrics
+
+// Registry returns the registry the metrics are served from, for
+// collectors of the service
+func (mp *MetricsProvider) Registry() *prometheus.Registry {
+	return mp.registry
+}
+
+// NewCounterVec registers a counter of the service in the namespace, with
+// the service, environment and version labels. It panics when a metric of
+// the same name is registered, like prometheus.MustRegister.
+func (mp *MetricsProvider) NewCounterVec(name, help string, labels ...string) *prometheus.CounterVec {
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   mp.namespace,
+		Name:        name,
+		Help:        help,
+		ConstLabels: mp.commonLabels,
+	}, labels)
+	mp.registry.MustRegister(counter)
+	return counter
+}
+
+// NewHistogramVec registers a histogram of the service like NewCounterVec.
+// Nil buckets are prometheus.DefBuckets.
+func (mp *MetricsProvider) NewHistogramVec(name, help string, buckets []float64, labels ...string) *prometheus.HistogramVec {
+	histogram := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace:   mp.namespace,
+		Name:        name,
+		Help:        help,
+		ConstLabels: mp.commonLabels,
+		Buckets:     buckets,
+	}, labels)
+	mp.registry.MustRegister(histogram)
+	return histogram
+}
+
+// NewGaugeVec registers a gauge of the service like NewCounterVec
+func (mp *MetricsProvider) NewGaugeVec(name, help string, labels ...string) *prometheus.GaugeVec {
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace:   mp.namespace,
+		Name:        name,
+		Help:        help,
+		ConstLabels: mp.commonLabels,
+	}, labels)
+	mp.registry.MustRegister(gauge)
+	return gauge
+}
+
 // Service Metrics Methods
 
 // RecordServiceError records service error
@@ -358,7 +411,7 @@ func (mp *MetricsProvider) trackUptime() {
 
 // Handler returns Prometheus HTTP handler
 func (mp *MetricsProvider) Handler() http.Handler {
-	return promhttp.HandlerFor(&mp.registry, promhttp.HandlerOpts{
+	return promhttp.HandlerFor(mp.registry, promhttp.HandlerOpts{
 		EnableOpenMetrics: true,
 	})
 }
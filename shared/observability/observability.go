@@ -6,7 +6,10 @@ import (
 	"net/http"
 	"time"
 
+	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	oteltrace "go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -29,8 +32,27 @@ type Config struct {
 	Errors      ErrorConfig
 }
 
-// New creates a new observability provider
+// New creates a new observability provider. The service name, environment
+// and version of config apply to its tracing, metrics and errors alike, so
+// services only set them once. A nil logger is replaced by NewLogger's.
 func New(config Config, logger *zap.Logger) (*Provider, error) {
+	config.Tracing.ServiceName, config.Tracing.Environment, config.Tracing.ServiceVersion = config.ServiceName, config.Environment, config.Version
+	config.Metrics.ServiceName, config.Metrics.Environment, config.Metrics.Version = config.ServiceName, config.Environment, config.Version
+	config.Errors.ServiceName, config.Errors.Environment = config.ServiceName, config.Environment
+
+	if logger == nil {
+		var err error
+		if logger, err = NewLogger(config.Environment); err != nil {
+			return nil, fmt.Errorf("failed to initialize logger: %w", err)
+		}
+	}
+	// Every log line of the service carries its name, version and environment
+	logger = logger.With(
+		zap.String("service", config.ServiceName),
+		zap.String("version", config.Version),
+		zap.String("environment", config.Environment),
+	)
+
 	// Initialize tracing
 	tracing, err := NewTracingProvider(config.Tracing, logger)
 	if err != nil {
@@ -54,15 +76,26 @@ func New(config Config, logger *zap.Logger) (*Provider, error) {
 		config:  config,
 	}
 
-	logger.Info("Observability provider initialized",
-		zap.String("service", config.ServiceName),
-		zap.String("environment", config.Environment),
-		zap.String("version", config.Version),
-	)
+	logger.Info("Observability provider initialized")
 
 	return provider, nil
 }
 
+// NewLogger builds the zap logger of a service: JSON at info level, or
+// human-readable at debug level in development
+func NewLogger(environment string) (*zap.Logger, error) {
+	if environment == "development" {
+		return zap.NewDevelopment()
+	}
+	return zap.NewProduction()
+}
+
+// Logger returns the logger of the service, tagged with its name, version
+// and environment
+func (p *Provider) Logger() *zap.Logger {
+	return p.logger
+}
+
 // Tracing returns the tracing provider
 func (p *Provider) Tracing() *TracingProvider {
 	return p.tracing
@@ -88,8 +121,10 @@ func (p *Provider) HTTPMiddleware() func(http.Handler) http.Handler {
 			p.metrics.IncrementActiveConnections()
 			defer p.metrics.DecrementActiveConnections()
 
-			// Start tracing span
-			ctx, span := p.tracing.StartSpan(r.Context(), fmt.Sprintf("HTTP %s %s", r.Method, r.URL.Path))
+			// Start tracing span, in the trace of the caller if any
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := p.tracing.StartSpan(ctx, fmt.Sprintf("HTTP %s %s", r.Method, r.URL.Path),
+				oteltrace.WithSpanKind(oteltrace.SpanKindServer))
 			defer span.End()
 
 			// Add request attributes to span
@@ -114,19 +149,23 @@ func (p *Provider) HTTPMiddleware() func(http.Handler) http.Handler {
 			)
 
 			// Execute request with context
-			next.ServeHTTP(wrapper, r.WithContext(ctx))
+			r = r.WithContext(ctx)
+			next.ServeHTTP(wrapper, r)
 
-			// Record metrics
+			// Record metrics by the pattern of the ServeMux route, set
+			// on the request once served
 			duration := time.Since(start)
 			userID := p.extractUserID(r)
-			endpoint := r.URL.Path
+			endpoint := r.Pattern
+			if endpoint == "" {
+				endpoint = UnmatchedRoute
+			}
 			statusCode := fmt.Sprintf("%d", wrapper.statusCode)
 
 			p.metrics.RecordHTTPRequest(
 				r.Method,
 				endpoint,
 				statusCode,
-				userID,
 				duration,
 				r.ContentLength,
 				int64(wrapper.size),
@@ -3,11 +3,15 @@ package observability
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
@@ -29,10 +33,16 @@ type TracingConfig struct {
 	ServiceName    string
 	ServiceVersion string
 	Environment    string
-	OTLPEndpoint   string
-	OTLPHeaders    map[string]string
-	SamplingRatio  float64
-	EnableConsole  bool
+	// OTLPEndpoint is the base URL of the OTLP/HTTP collector, such as
+	// http://otel-collector:4318; spans are sent to its /v1/traces. Spans
+	// are still created without it, for the trace IDs of logs and errors,
+	// but not exported.
+	OTLPEndpoint string
+	OTLPHeaders  map[string]string
+	// SamplingRatio is the share of traces started here that are sampled;
+	// traces continued from a caller follow its decision
+	SamplingRatio float64
+	EnableConsole bool
 }
 
 // NewTracingProvider creates a new tracing provider
@@ -53,26 +63,29 @@ func NewTracingProvider(config TracingConfig, logger *zap.Logger) (*TracingProvi
 		return nil, fmt.Errorf("failed to create resource: %w", err)
 	}
 
-	// Create OTLP HTTP exporter
-	otlpExporter, err := otlptracehttp.New(
-		context.Background(),
-		otlptracehttp.WithEndpoint(config.OTLPEndpoint),
-		otlptracehttp.WithHeaders(config.OTLPHeaders),
-		otlptracehttp.WithInsecure(), // Use HTTPS in production
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	options := []trace.TracerProviderOption{
+		trace.WithResource(res),
+		trace.WithSampler(trace.ParentBased(trace.TraceIDRatioBased(config.SamplingRatio))),
 	}
 
-	// Create trace provider with batch processor
-	tp := trace.NewTracerProvider(
-		trace.WithResource(res),
-		trace.WithBatcher(otlpExporter,
+	// Create OTLP HTTP exporter with batch processor; plain http endpoints
+	// are sent to without TLS
+	if config.OTLPEndpoint != "" {
+		otlpExporter, err := otlptracehttp.New(
+			context.Background(),
+			otlptracehttp.WithEndpointURL(strings.TrimSuffix(config.OTLPEndpoint, "/")+"/v1/traces"),
+			otlptracehttp.WithHeaders(config.OTLPHeaders),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+		}
+		options = append(options, trace.WithBatcher(otlpExporter,
 			trace.WithBatchTimeout(time.Second*5),
 			trace.WithMaxExportBatchSize(512),
-		),
-		trace.WithSampler(trace.TraceIDRatioBased(config.SamplingRatio)),
-	)
+		))
+	}
+
+	tp := trace.NewTracerProvider(options...)
 
 	// Set global trace provider
 	otel.SetTracerProvider(tp)
@@ -87,8 +100,6 @@ func NewTracingProvider(config TracingConfig, logger *zap.Logger) (*TracingProvi
 	tracer := tp.Tracer(config.ServiceName)
 
 	logger.Info("Tracing provider initialized",
-		zap.String("service", config.ServiceName),
-		zap.String("environment", config.Environment),
 		zap.String("otlp_endpoint", config.OTLPEndpoint),
 		zap.Float64("sampling_ratio", config.SamplingRatio),
 	)
@@ -118,7 +129,7 @@ func (t *TracingProvider) AddSpanEvent(span oteltrace.Span, name string, attrs .
 // RecordError records an error in the current span
 func (t *TracingProvider) RecordError(span oteltrace.Span, err error, attrs ...attribute.KeyValue) {
 	span.RecordError(err, oteltrace.WithAttributes(attrs...))
-	span.SetStatus(oteltrace.StatusCodeError, err.Error())
+	span.SetStatus(codes.Error, err.Error())
 }
 
 // GetTracer returns the tracer instance
@@ -150,19 +161,48 @@ func (t *TracingProvider) Shutdown(ctx context.Context) error {
 	return t.provider.Shutdown(ctx)
 }
 
-// DefaultTracingConfig returns default tracing configuration
+// DefaultTracingConfig returns the tracing configuration of the standard
+// OpenTelemetry variables every service reads:
+//
+//	OTEL_EXPORTER_OTLP_ENDPOINT  collector base URL; unset exports nothing
+//	OTEL_EXPORTER_OTLP_HEADERS   key=value pairs separated by commas
+//	OTEL_TRACES_SAMPLER_ARG      sampling ratio, 1 by default
+//	OTEL_SDK_DISABLED            true exports nothing
 func DefaultTracingConfig(serviceName string) TracingConfig {
+	endpoint := getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	if getEnv("OTEL_SDK_DISABLED", "false") == "true" {
+		endpoint = ""
+	}
+	ratio, err := strconv.ParseFloat(getEnv("OTEL_TRACES_SAMPLER_ARG", "1"), 64)
+	if err != nil || ratio < 0 || ratio > 1 {
+		ratio = 1
+	}
 	return TracingConfig{
 		ServiceName:    serviceName,
 		ServiceVersion: getEnv("SERVICE_VERSION", "1.0.0"),
 		Environment:    getEnv("ENVIRONMENT", "development"),
-		OTLPEndpoint:   getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318"),
-		OTLPHeaders: map[string]string{
-			"Content-Type": "application/json",
-		},
-		SamplingRatio: 1.0, // Sample all traces in development
-		EnableConsole: getEnv("ENVIRONMENT", "development") == "development",
+		OTLPEndpoint:   endpoint,
+		OTLPHeaders:    parseHeaders(getEnv("OTEL_EXPORTER_OTLP_HEADERS", "")),
+		SamplingRatio:  ratio,
+		EnableConsole:  getEnv("ENVIRONMENT", "development") == "development",
+	}
+}
+
+// parseHeaders parses the key=value pairs of OTEL_EXPORTER_OTLP_HEADERS,
+// whose values may be URL encoded
+func parseHeaders(raw string) map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			continue
+		}
+		if decoded, err := url.QueryUnescape(strings.TrimSpace(value)); err == nil {
+			value = decoded
+		}
+		headers[strings.TrimSpace(key)] = value
 	}
+	return headers
 }
 
 // getEnv gets environment variable with fallback
@@ -0,0 +1,153 @@
+// Package startup holds back the start of a service until the dependencies
+// it cannot run without are reachable. On a cold start of the cluster Kafka,
+// PostgreSQL or Redis may come up after the services using them; rather than
+// exiting at once and being restarted in a crash loop, a service retries its
+// connections with exponential backoff for a bounded time, and exits with
+// ExitUnavailable only when they are still down at the deadline.
+package startup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ExitUnavailable is the exit code of a service whose dependencies were still
+// unavailable at the startup deadline, telling it apart from a crash or an
+// invalid configuration, which exit with 1
+const ExitUnavailable = 3
+
+// Defaults of the Gate
+const (
+	DefaultTimeout        = 2 * time.Minute
+	DefaultInitialBackoff = 500 * time.Millisecond
+	DefaultMaxBackoff     = 15 * time.Second
+)
+
+// ErrUnavailable is returned when a dependency is still unavailable at the
+// startup deadline
+var ErrUnavailable = errors.New("dependency unavailable at startup")
+
+// Check connects to or probes a dependency. Run returns an error while the
+// dependency is unavailable, and must return once ctx is done. Run is called
+// again after each failure, so it must not leave anything half set up.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Attempt is the outcome of running a check once. Err is nil when the check
+// passed; otherwise Wait is how long the gate waits before running it again.
+type Attempt struct {
+	Check  string
+	Number int
+	Err    error
+	Wait   time.Duration
+}
+
+// Gate runs the checks of the dependencies of a service until they pass. The
+// zero value uses the defaults.
+type Gate struct {
+	// Timeout bounds the time spent waiting for every check to pass
+	Timeout time.Duration
+	// InitialBackoff is the wait after the first failure of a check,
+	// doubled after each following failure up to MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Observe, when set, is called after every attempt, e.g. to log the
+	// failures or report which dependencies are still awaited
+	Observe func(Attempt)
+
+	// now and sleep are replaced by the tests to run on a fake clock
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// WaitFor runs checks in order with the default Gate; see Gate.WaitFor
+func WaitFor(ctx context.Context, checks ...Check) error {
+	return Gate{}.WaitFor(ctx, checks...)
+}
+
+// WaitFor runs checks in order, running each again with exponential backoff
+// until it passes before moving to the next. It returns an error wrapping
+// ErrUnavailable and the last failure when a check has not passed within
+// Timeout, or the error of ctx when ctx is done first.
+func (g Gate) WaitFor(ctx context.Context, checks ...Check) error {
+	g = g.withDefaults()
+	ctx, cancel := context.WithTimeout(ctx, g.Timeout)
+	defer cancel()
+	deadline := g.now().Add(g.Timeout)
+
+	for _, check := range checks {
+		backoff := g.InitialBackoff
+		for attempt := 1; ; attempt++ {
+			err := check.Run(ctx)
+			if err == nil {
+				g.observe(Attempt{Check: check.Name, Number: attempt})
+				break
+			}
+
+			wait := min(backoff, deadline.Sub(g.now()))
+			if wait <= 0 || ctx.Err() != nil {
+				wait = 0
+			}
+			g.observe(Attempt{Check: check.Name, Number: attempt, Err: err, Wait: wait})
+			if wait == 0 {
+				return g.failed(ctx, check.Name, attempt, err)
+			}
+			if sleepErr := g.sleep(ctx, wait); sleepErr != nil {
+				return g.failed(ctx, check.Name, attempt, err)
+			}
+			backoff = min(2*backoff, g.MaxBackoff)
+		}
+	}
+	return nil
+}
+
+// failed reports check name as unavailable after attempts, unless the parent
+// of ctx was canceled, e.g. by a shutdown signal during startup
+func (g Gate) failed(ctx context.Context, name string, attempts int, err error) error {
+	if ctx.Err() != nil && !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return ctx.Err()
+	}
+	return fmt.Errorf("%w: %s after %d attempts in %s: %v", ErrUnavailable, name, attempts, g.Timeout, err)
+}
+
+func (g Gate) observe(attempt Attempt) {
+	if g.Observe != nil {
+		g.Observe(attempt)
+	}
+}
+
+func (g Gate) withDefaults() Gate {
+	if g.Timeout <= 0 {
+		g.Timeout = DefaultTimeout
+	}
+	if g.InitialBackoff <= 0 {
+		g.InitialBackoff = DefaultInitialBackoff
+	}
+	if g.MaxBackoff <= 0 {
+		g.MaxBackoff = DefaultMaxBackoff
+	}
+	g.MaxBackoff = max(g.MaxBackoff, g.InitialBackoff)
+	if g.now == nil {
+		g.now = time.Now
+	}
+	if g.sleep == nil {
+		g.sleep = sleep
+	}
+	return g
+}
+
+// sleep waits for d, returning early with the error of ctx once it is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
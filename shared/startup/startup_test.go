@@ -0,0 +1,135 @@
+package startup
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClock is advanced by the sleeps of the gate, so the tests wait for
+// minutes of backoff without waiting
+type fakeClock struct {
+	now   time.Time
+	slept []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	c.slept = append(c.slept, d)
+	c.now = c.now.Add(d)
+	return ctx.Err()
+}
+
+func (c *fakeClock) gate(g Gate) Gate {
+	g.now = c.Now
+	g.sleep = c.Sleep
+	return g
+}
+
+// availableAfter is a check of a dependency that comes up after d
+func availableAfter(clock *fakeClock, name string, d time.Duration) (Check, *int) {
+	up := clock.now.Add(d)
+	attempts := 0
+	return Check{Name: name, Run: func(ctx context.Context) error {
+		attempts++
+		if clock.now.Before(up) {
+			return errors.New("connection refused")
+		}
+		return nil
+	}}, &attempts
+}
+
+func TestWaitForDependencyAvailableAfter10Seconds(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	start := clock.now
+	kafka, kafkaAttempts := availableAfter(clock, "kafka", 10*time.Second)
+	connect, connectAttempts := availableAfter(clock, "connect", 0)
+
+	var observed []Attempt
+	gate := clock.gate(Gate{
+		InitialBackoff: time.Second,
+		MaxBackoff:     4 * time.Second,
+		Observe:        func(a Attempt) { observed = append(observed, a) },
+	})
+	if err := gate.WaitFor(context.Background(), kafka, connect); err != nil {
+		t.Fatalf("WaitFor = %v, want the dependencies to pass", err)
+	}
+
+	// 1s, 2s, 4s, then capped at 4s: up after 11s, on the fifth attempt
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second}
+	if len(clock.slept) != len(want) {
+		t.Fatalf("slept %v, want %v", clock.slept, want)
+	}
+	for i := range want {
+		if clock.slept[i] != want[i] {
+			t.Fatalf("slept %v, want %v", clock.slept, want)
+		}
+	}
+	if elapsed := clock.now.Sub(start); elapsed != 11*time.Second {
+		t.Errorf("waited %s, want 11s", elapsed)
+	}
+	if *kafkaAttempts != 5 || *connectAttempts != 1 {
+		t.Errorf("attempts = %d kafka, %d connect; want 5 and 1", *kafkaAttempts, *connectAttempts)
+	}
+
+	if len(observed) != 6 {
+		t.Fatalf("observed %d attempts, want 6", len(observed))
+	}
+	if last := observed[4]; last.Check != "kafka" || last.Number != 5 || last.Err != nil {
+		t.Errorf("observed %+v, want kafka passing on attempt 5", last)
+	}
+	if first := observed[0]; first.Err == nil || first.Wait != time.Second {
+		t.Errorf("observed %+v, want a failure waiting 1s", first)
+	}
+}
+
+func TestWaitForDeadline(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	start := clock.now
+	kafka, _ := availableAfter(clock, "kafka", 0)
+	connect, connectAttempts := availableAfter(clock, "connect", 10*time.Second)
+
+	gate := clock.gate(Gate{Timeout: 5 * time.Second, InitialBackoff: time.Second, MaxBackoff: 4 * time.Second})
+	err := gate.WaitFor(context.Background(), kafka, connect)
+	if !errors.Is(err, ErrUnavailable) || !strings.Contains(err.Error(), "connect") {
+		t.Fatalf("WaitFor = %v, want ErrUnavailable naming connect", err)
+	}
+	// Backoff is cut short at the deadline: 1s, 2s, then the 2s left
+	if elapsed := clock.now.Sub(start); elapsed != 5*time.Second {
+		t.Errorf("waited %s, want the 5s timeout", elapsed)
+	}
+	if *connectAttempts != 4 {
+		t.Errorf("connect attempts = %d, want 4", *connectAttempts)
+	}
+}
+
+func TestWaitForCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	check := Check{Name: "postgres", Run: func(ctx context.Context) error {
+		cancel()
+		return errors.New("connection refused")
+	}}
+
+	err := Gate{InitialBackoff: time.Hour}.WaitFor(ctx, check)
+	if !errors.Is(err, context.Canceled) || errors.Is(err, ErrUnavailable) {
+		t.Fatalf("WaitFor = %v, want context.Canceled", err)
+	}
+}
+
+func TestWaitForRealClock(t *testing.T) {
+	up := time.Now().Add(50 * time.Millisecond)
+	check := Check{Name: "redis", Run: func(ctx context.Context) error {
+		if time.Now().Before(up) {
+			return errors.New("connection refused")
+		}
+		return nil
+	}}
+
+	gate := Gate{Timeout: 5 * time.Second, InitialBackoff: 10 * time.Millisecond, MaxBackoff: 20 * time.Millisecond}
+	if err := gate.WaitFor(context.Background(), check); err != nil {
+		t.Fatalf("WaitFor = %v, want redis to pass", err)
+	}
+}
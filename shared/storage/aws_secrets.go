@@ -0,0 +1,223 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AWSSecrets resolves secrets from AWS Secrets Manager: key storage/acme is
+// the string value of the secret named storage/acme, as the aws-secrets
+// provider of shared/secrets stores it
+type AWSSecrets struct {
+	api *awsJSON
+}
+
+// NewAWSSecrets creates a resolver calling Secrets Manager in region, at
+// endpoint if set, such as LocalStack
+func NewAWSSecrets(endpoint, region, accessKeyID, secretAccessKey, sessionToken string) (*AWSSecrets, error) {
+	api, err := newAWSJSON("secretsmanager", endpoint, region, accessKeyID, secretAccessKey, sessionToken)
+	if err != nil {
+		return nil, err
+	}
+	return &AWSSecrets{api: api}, nil
+}
+
+// GetSecret returns the secret of key
+func (a *AWSSecrets) GetSecret(ctx context.Context, key string) (string, error) {
+	var result struct {
+		SecretString *string `json:"SecretString"`
+		SecretBinary []byte  `json:"SecretBinary"`
+	}
+	if err := a.api.call(ctx, "secretsmanager.GetSecretValue", map[string]string{"SecretId": key}, &result); err != nil {
+		return "", fmt.Errorf("secret %s: %w", key, err)
+	}
+	if result.SecretString != nil {
+		return *result.SecretString, nil
+	}
+	if result.SecretBinary != nil {
+		return string(result.SecretBinary), nil
+	}
+	return "", fmt.Errorf("secret %s has no value", key)
+}
+
+// AWSParameters resolves secrets from the parameters of AWS Systems Manager:
+// key storage/acme is the decrypted parameter storage/acme under the base
+// path, as the aws-ssm provider of shared/secrets stores it
+type AWSParameters struct {
+	api      *awsJSON
+	basePath string
+}
+
+// NewAWSParameters creates a resolver reading the parameters under basePath
+// in region, at endpoint if set
+func NewAWSParameters(endpoint, region, accessKeyID, secretAccessKey, sessionToken, basePath string) (*AWSParameters, error) {
+	api, err := newAWSJSON("ssm", endpoint, region, accessKeyID, secretAccessKey, sessionToken)
+	if err != nil {
+		return nil, err
+	}
+	return &AWSParameters{api: api, basePath: strings.TrimRight(basePath, "/")}, nil
+}
+
+// GetSecret returns the secret of key
+func (a *AWSParameters) GetSecret(ctx context.Context, key string) (string, error) {
+	name := a.basePath + "/" + key
+	var result struct {
+		Parameter *struct {
+			Value string `json:"Value"`
+		} `json:"Parameter"`
+	}
+	input := map[string]interface{}{"Name": name, "WithDecryption": true}
+	if err := a.api.call(ctx, "AmazonSSM.GetParameter", input, &result); err != nil {
+		return "", fmt.Errorf("parameter %s: %w", name, err)
+	}
+	if result.Parameter == nil {
+		return "", fmt.Errorf("parameter %s not found", name)
+	}
+	return result.Parameter.Value, nil
+}
+
+// awsJSON calls an AWS service speaking the JSON 1.1 protocol, signing its
+// requests with SigV4 headers
+type awsJSON struct {
+	service         string
+	endpoint        *url.URL
+	region          string
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+	client          *http.Client
+}
+
+func newAWSJSON(service, endpoint, region, accessKeyID, secretAccessKey, sessionToken string) (*awsJSON, error) {
+	if accessKeyID == "" || secretAccessKey == "" {
+		return nil, fmt.Errorf("aws credentials are required by the %s secrets provider", service)
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.%s.amazonaws.com", service, region)
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid %s endpoint %q", service, endpoint)
+	}
+
+	return &awsJSON{
+		service:         service,
+		endpoint:        u,
+		region:          region,
+		accessKeyID:     accessKeyID,
+		secretAccessKey: secretAccessKey,
+		sessionToken:    sessionToken,
+		client:          &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// call sends input to the operation target and decodes its result into
+// output. Errors carry the error type AWS returns, never the input.
+func (a *awsJSON) call(ctx context.Context, target string, input, output interface{}) error {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", target)
+	a.sign(req, body, time.Now())
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s request failed: %w", a.service, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("%s response unreadable: %w", a.service, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Type string `json:"__type"`
+		}
+		json.Unmarshal(data, &failure)
+		if i := strings.LastIndex(failure.Type, "#"); i >= 0 {
+			failure.Type = failure.Type[i+1:]
+		}
+		return fmt.Errorf("%s %s failed with status %d %s", a.service, target, resp.StatusCode, failure.Type)
+	}
+	if err := json.Unmarshal(data, output); err != nil {
+		return fmt.Errorf("invalid %s response: %w", a.service, err)
+	}
+	return nil
+}
+
+// sign adds the SigV4 Authorization header of req, signing its host,
+// content type, target and date headers and the hash of body
+func (a *awsJSON) sign(req *http.Request, body []byte, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	shortDate := now.Format("20060102")
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", shortDate, a.region, a.service)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if a.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", a.sessionToken)
+	}
+	signed := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		signed[strings.ToLower(name)] = strings.TrimSpace(values[0])
+	}
+	names := make([]string, 0, len(signed))
+	for name := range signed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + signed[name] + "\n")
+	}
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	payload := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		strings.Join(names, ";"),
+		hex.EncodeToString(payload[:]),
+	}, "\n")
+
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+a.secretAccessKey), shortDate)
+	signingKey = hmacSHA256(signingKey, a.region)
+	signingKey = hmacSHA256(signingKey, a.service)
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		a.accessKeyID, scope, strings.Join(names, ";"), signature))
+}
@@ -0,0 +1,459 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// azureVersion is the version of the Blob service REST API requests
+	// and shared access signatures are made with
+	azureVersion = "2020-12-06"
+	// azureBlockSize is the size of the blocks of streamed uploads. It is
+	// the memory a streamed upload holds.
+	azureBlockSize = 8 << 20
+	// azureCopyPoll is how often a pending copy is polled
+	azureCopyPoll = 500 * time.Millisecond
+)
+
+// Azure talks to Azure Blob Storage, or Azurite, through its REST API,
+// authenticated with the Shared Key of the storage account, and signs URLs
+// with service shared access signatures, so no SDK is required.
+//
+// Uploads to a pre-signed URL must name the blob type in a header clients
+// don't send, so Azure can't sign PUT URLs: a Proxy serves them.
+type Azure struct {
+	endpoint  *url.URL
+	account   string
+	key       []byte
+	container string
+	client    *http.Client
+	now       func() time.Time
+}
+
+// azureEnumerationResults is the response of List Blobs
+type azureEnumerationResults struct {
+	Blobs []struct {
+		Name       string `xml:"Name"`
+		Properties struct {
+			LastModified  string `xml:"Last-Modified"`
+			ContentLength int64  `xml:"Content-Length"`
+			ContentType   string `xml:"Content-Type"`
+		} `xml:"Properties"`
+	} `xml:"Blobs>Blob"`
+	NextMarker string `xml:"NextMarker"`
+}
+
+type azureBlockList struct {
+	XMLName xml.Name `xml:"BlockList"`
+	Latest  []string `xml:"Latest"`
+}
+
+// NewAzure creates a new Azure Blob Storage client for a container of the
+// storage account. endpoint is empty for the public cloud.
+func NewAzure(endpoint, account, accountKey, container string) (*Azure, error) {
+	if account == "" || accountKey == "" {
+		return nil, fmt.Errorf("azure storage account and key are required")
+	}
+	if container == "" {
+		return nil, fmt.Errorf("azure container is required")
+	}
+	key, err := base64.StdEncoding.DecodeString(accountKey)
+	if err != nil {
+		return nil, fmt.Errorf("azure account key is not base64: %w", err)
+	}
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.blob.core.windows.net", account)
+	}
+	u, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid azure endpoint: %s", endpoint)
+	}
+
+	return &Azure{
+		endpoint:  u,
+		account:   account,
+		key:       key,
+		container: container,
+		client:    &http.Client{Timeout: 30 * time.Second},
+		now:       time.Now,
+	}, nil
+}
+
+// PresignPut is not supported: see Azure
+func (s *Azure) PresignPut(ctx context.Context, key, contentType string, expires time.Duration) (string, error) {
+	return "", ErrPresignUnsupported
+}
+
+// PresignGet returns a URL of the blob with a read-only shared access
+// signature
+func (s *Azure) PresignGet(ctx context.Context, key string, expires time.Duration) (string, error) {
+	now := s.now().UTC()
+	query := url.Values{
+		"sv": {azureVersion},
+		"sr": {"b"},
+		"sp": {"r"},
+		"st": {now.Add(-5 * time.Minute).Format(time.RFC3339)},
+		"se": {now.Add(expires).Format(time.RFC3339)},
+	}
+	stringToSign := strings.Join([]string{
+		"r",
+		query.Get("st"),
+		query.Get("se"),
+		"/blob/" + s.account + "/" + s.container + "/" + key,
+		"", // signed identifier
+		"", // signed IP
+		"", // signed protocol
+		azureVersion,
+		"b",
+		"",                 // snapshot time
+		"",                 // encryption scope
+		"", "", "", "", "", // response headers
+	}, "\n")
+	query.Set("sig", s.sign(stringToSign))
+
+	return s.blobURL(key) + "?" + query.Encode(), nil
+}
+
+// Open downloads the blob
+func (s *Azure) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ErrObjectNotFound
+	default:
+		resp.Body.Close()
+		return nil, fmt.Errorf("azure get blob failed with status %d", resp.StatusCode)
+	}
+}
+
+// Put uploads the blob with a single Put Blob request
+func (s *Azure) Put(ctx context.Context, key, contentType string, body []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, key, nil, map[string]string{
+		"Content-Type":   contentType,
+		"x-ms-blob-type": "BlockBlob",
+	}, body)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("azure put blob failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// PutStream uploads the blob block by block, then commits the block list,
+// so only one block is held in memory. Blobs smaller than a block are sent
+// with a single Put Blob. Blocks of a failed upload are never committed,
+// and the service discards them.
+func (s *Azure) PutStream(ctx context.Context, key, contentType string, body io.Reader) error {
+	block := make([]byte, azureBlockSize)
+	n, err := io.ReadFull(body, block)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return s.Put(ctx, key, contentType, block[:n])
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read object: %w", err)
+	}
+
+	var ids []string
+	for number := 0; len(block) > 0; number++ {
+		// Block IDs of a blob must all have the same length
+		id := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("block-%08d", number)))
+		resp, err := s.do(ctx, http.MethodPut, key, map[string]string{"comp": "block", "blockid": id}, nil, block)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			return fmt.Errorf("azure put block %d failed with status %d", number, resp.StatusCode)
+		}
+		ids = append(ids, id)
+
+		block = block[:cap(block)]
+		n, err := io.ReadFull(body, block)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("failed to read object: %w", err)
+		}
+		block = block[:n]
+	}
+
+	list, err := xml.Marshal(azureBlockList{Latest: ids})
+	if err != nil {
+		return fmt.Errorf("failed to encode block list: %w", err)
+	}
+	resp, err := s.do(ctx, http.MethodPut, key, map[string]string{"comp": "blocklist"}, map[string]string{
+		"Content-Type":           "application/xml",
+		"x-ms-blob-content-type": contentType,
+	}, append([]byte(xml.Header), list...))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("azure put block list failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Move copies the blob server-side, waiting for the copy to complete, and
+// deletes the source
+func (s *Azure) Move(ctx context.Context, srcKey, dstKey string) error {
+	resp, err := s.do(ctx, http.MethodPut, dstKey, nil, map[string]string{"x-ms-copy-source": s.blobURL(srcKey)}, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrObjectNotFound
+	case resp.StatusCode != http.StatusAccepted:
+		return fmt.Errorf("azure copy blob failed with status %d", resp.StatusCode)
+	}
+
+	// Copies within an account usually complete at once
+	status := resp.Header.Get("x-ms-copy-status")
+	for status == "pending" {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(azureCopyPoll):
+		}
+		resp, err := s.do(ctx, http.MethodHead, dstKey, nil, nil, nil)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("azure get blob properties failed with status %d", resp.StatusCode)
+		}
+		status = resp.Header.Get("x-ms-copy-status")
+	}
+	if status != "success" {
+		return fmt.Errorf("azure copy blob ended with status %q", status)
+	}
+
+	return s.Delete(ctx, srcKey)
+}
+
+// Stat reads the properties of the blob
+func (s *Azure) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	resp, err := s.do(ctx, http.MethodHead, key, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrObjectNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("azure get blob properties failed with status %d", resp.StatusCode)
+	}
+
+	size, _ := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	modified, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+
+	return &ObjectInfo{
+		Key:          key,
+		Size:         size,
+		ContentType:  resp.Header.Get("Content-Type"),
+		LastModified: modified,
+	}, nil
+}
+
+// Delete deletes the blob
+func (s *Azure) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("azure delete blob failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// List pages through the blobs of the container
+func (s *Azure) List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	params := map[string]string{"restype": "container", "comp": "list", "prefix": prefix}
+	for {
+		resp, err := s.doContainer(ctx, http.MethodGet, params)
+		if err != nil {
+			return err
+		}
+		var page azureEnumerationResults
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return fmt.Errorf("azure list blobs failed with status %d", resp.StatusCode)
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("azure list blobs returned an invalid page: %w", err)
+		}
+
+		for _, blob := range page.Blobs {
+			modified, _ := http.ParseTime(blob.Properties.LastModified)
+			info := ObjectInfo{Key: blob.Name, Size: blob.Properties.ContentLength, ContentType: blob.Properties.ContentType, LastModified: modified}
+			if err := fn(info); err != nil {
+				return err
+			}
+		}
+		if page.NextMarker == "" {
+			return nil
+		}
+		params["marker"] = page.NextMarker
+	}
+}
+
+// Check reads the properties of the container
+func (s *Azure) Check(ctx context.Context) error {
+	resp, err := s.doContainer(ctx, http.MethodGet, map[string]string{"restype": "container"})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return fmt.Errorf("azure container %s not found", s.container)
+	case http.StatusForbidden:
+		return fmt.Errorf("azure credentials were refused for container %s", s.container)
+	default:
+		return fmt.Errorf("azure get container properties failed with status %d", resp.StatusCode)
+	}
+}
+
+// blobURL is the URL of the blob of key
+func (s *Azure) blobURL(key string) string {
+	return s.endpoint.String() + "/" + s.container + "/" + encodePath(key)
+}
+
+// do sends a request for the blob of key
+func (s *Azure) do(ctx context.Context, method, key string, params, headers map[string]string, body []byte) (*http.Response, error) {
+	return s.send(ctx, method, s.blobURL(key), params, headers, body)
+}
+
+// doContainer sends a request for the container
+func (s *Azure) doContainer(ctx context.Context, method string, params map[string]string) (*http.Response, error) {
+	return s.send(ctx, method, s.endpoint.String()+"/"+s.container, params, nil, nil)
+}
+
+// send sends a request authorized with the Shared Key of the account
+func (s *Azure) send(ctx context.Context, method, target string, params, headers map[string]string, body []byte) (*http.Response, error) {
+	query := url.Values{}
+	for k, v := range params {
+		query.Set(k, v)
+	}
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("x-ms-date", s.now().UTC().Format(http.TimeFormat))
+	req.Header.Set("x-ms-version", azureVersion)
+	req.Header.Set("Authorization", "SharedKey "+s.account+":"+s.sign(s.stringToSign(req)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("azure %s request failed: %w", strings.ToLower(method), err)
+	}
+	return resp, nil
+}
+
+// stringToSign is the Shared Key string to sign of req
+func (s *Azure) stringToSign(req *http.Request) string {
+	contentLength := ""
+	if req.ContentLength > 0 {
+		contentLength = strconv.FormatInt(req.ContentLength, 10)
+	}
+
+	var names []string
+	for name := range req.Header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-ms-") {
+			names = append(names, lower)
+		}
+	}
+	sort.Strings(names)
+	var headers strings.Builder
+	for _, name := range names {
+		headers.WriteString(name + ":" + strings.TrimSpace(req.Header.Get(name)) + "\n")
+	}
+
+	// The resource is the path of the URL below the account, which is in
+	// the path too on Azurite
+	var resource strings.Builder
+	resource.WriteString("/" + s.account + req.URL.EscapedPath())
+	query := req.URL.Query()
+	params := make([]string, 0, len(query))
+	for name := range query {
+		params = append(params, name)
+	}
+	sort.Strings(params)
+	for _, name := range params {
+		values := query[name]
+		sort.Strings(values)
+		resource.WriteString("\n" + strings.ToLower(name) + ":" + strings.Join(values, ","))
+	}
+
+	return strings.Join([]string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		contentLength,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		"", // Date, superseded by x-ms-date
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+		headers.String() + resource.String(),
+	}, "\n")
+}
+
+// sign signs data with the account key
+func (s *Azure) sign(data string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(data))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,485 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// gcsDefaultEndpoint serves both the JSON API and the signed URLs
+	gcsDefaultEndpoint = "https://storage.googleapis.com"
+	gcsDefaultTokenURI = "https://oauth2.googleapis.com/token"
+	gcsScope           = "https://www.googleapis.com/auth/devstorage.read_write"
+	// gcsMaxPresignExpiry is the longest validity of a V4 signed URL
+	gcsMaxPresignExpiry = 7 * 24 * time.Hour
+)
+
+// GCS talks to Google Cloud Storage through its JSON API, authenticated
+// with the OAuth2 tokens of a service account, and signs URLs with the V4
+// signing process of its key, so no SDK is required. Without a service
+// account, as with fake-gcs-server, requests are sent unauthenticated and
+// URLs can't be signed.
+type GCS struct {
+	endpoint *url.URL
+	bucket   string
+	account  *gcsServiceAccount
+	client   *http.Client
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// gcsServiceAccount is the JSON key of a service account
+type gcsServiceAccount struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+
+	key *rsa.PrivateKey
+}
+
+// gcsObject is the resource of an object in the JSON API
+type gcsObject struct {
+	Name        string    `json:"name"`
+	Size        string    `json:"size"`
+	ContentType string    `json:"contentType"`
+	Updated     time.Time `json:"updated"`
+}
+
+// NewGCS creates a new Google Cloud Storage client for bucket, using the
+// service account of credentialsJSON when it is set
+func NewGCS(endpoint, bucket, credentialsJSON string) (*GCS, error) {
+	if bucket == "" {
+		return nil, fmt.Errorf("gcs bucket is required")
+	}
+	if endpoint == "" {
+		endpoint = gcsDefaultEndpoint
+	}
+	u, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid gcs endpoint: %s", endpoint)
+	}
+
+	s := &GCS{
+		endpoint: u,
+		bucket:   bucket,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+	if credentialsJSON != "" {
+		if s.account, err = parseGCSServiceAccount(credentialsJSON); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// parseGCSServiceAccount decodes the JSON key of a service account and its
+// RSA private key
+func parseGCSServiceAccount(credentialsJSON string) (*gcsServiceAccount, error) {
+	var account gcsServiceAccount
+	if err := json.Unmarshal([]byte(credentialsJSON), &account); err != nil {
+		return nil, fmt.Errorf("gcs credentials are not a service account key: %w", err)
+	}
+	if account.ClientEmail == "" || account.PrivateKey == "" {
+		return nil, fmt.Errorf("gcs credentials have no client_email or private_key")
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = gcsDefaultTokenURI
+	}
+
+	block, _ := pem.Decode([]byte(account.PrivateKey))
+	if block == nil {
+		return nil, fmt.Errorf("gcs private key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		if parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return nil, fmt.Errorf("invalid gcs private key: %w", err)
+		}
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("gcs private key is not an RSA key")
+	}
+	account.key = key
+	return &account, nil
+}
+
+// PresignPut returns a V4 signed PUT URL bound to the given content type
+func (s *GCS) PresignPut(ctx context.Context, key, contentType string, expires time.Duration) (string, error) {
+	return s.presign(http.MethodPut, key, expires, map[string]string{"content-type": contentType}, time.Now())
+}
+
+// PresignGet returns a V4 signed GET URL for the object
+func (s *GCS) PresignGet(ctx context.Context, key string, expires time.Duration) (string, error) {
+	return s.presign(http.MethodGet, key, expires, nil, time.Now())
+}
+
+// Open downloads the object media
+func (s *GCS) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, http.MethodGet, s.objectURL(key)+"?alt=media", "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ErrObjectNotFound
+	default:
+		resp.Body.Close()
+		return nil, fmt.Errorf("gcs get object failed with status %d", resp.StatusCode)
+	}
+}
+
+// Put uploads the object with a single media upload
+func (s *GCS) Put(ctx context.Context, key, contentType string, body []byte) error {
+	return s.upload(ctx, key, contentType, bytes.NewReader(body))
+}
+
+// PutStream uploads the object with a single media upload whose body is
+// streamed with chunked encoding, so nothing is held in memory. An object
+// is only created once its upload completes, so a failure stores nothing.
+func (s *GCS) PutStream(ctx context.Context, key, contentType string, body io.Reader) error {
+	return s.upload(ctx, key, contentType, body)
+}
+
+// Move rewrites the object to its new key, then deletes the source
+func (s *GCS) Move(ctx context.Context, srcKey, dstKey string) error {
+	rewrite := s.objectURL(srcKey) + "/rewriteTo/b/" + url.PathEscape(s.bucket) + "/o/" + url.PathEscape(dstKey)
+	var token string
+	for {
+		target := rewrite
+		if token != "" {
+			target += "?rewriteToken=" + url.QueryEscape(token)
+		}
+		resp, err := s.do(ctx, http.MethodPost, target, "", nil)
+		if err != nil {
+			return err
+		}
+		var result struct {
+			Done         bool   `json:"done"`
+			RewriteToken string `json:"rewriteToken"`
+		}
+		status := resp.StatusCode
+		if status == http.StatusOK {
+			err = json.NewDecoder(resp.Body).Decode(&result)
+		}
+		resp.Body.Close()
+
+		switch {
+		case status == http.StatusNotFound:
+			return ErrObjectNotFound
+		case status != http.StatusOK:
+			return fmt.Errorf("gcs rewrite object failed with status %d", status)
+		case err != nil:
+			return fmt.Errorf("gcs rewrite object returned an invalid body: %w", err)
+		}
+		// Large objects are rewritten over several calls
+		if result.Done || result.RewriteToken == "" {
+			break
+		}
+		token = result.RewriteToken
+	}
+
+	return s.Delete(ctx, srcKey)
+}
+
+// Stat reads the metadata of the object
+func (s *GCS) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	resp, err := s.do(ctx, http.MethodGet, s.objectURL(key), "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrObjectNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("gcs get object metadata failed with status %d", resp.StatusCode)
+	}
+	var object gcsObject
+	if err := json.NewDecoder(resp.Body).Decode(&object); err != nil {
+		return nil, fmt.Errorf("gcs get object metadata returned an invalid body: %w", err)
+	}
+	info := object.info()
+	return &info, nil
+}
+
+// Delete deletes the object
+func (s *GCS) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, s.objectURL(key), "", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("gcs delete object failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// List pages through the objects of the bucket
+func (s *GCS) List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	query := url.Values{"prefix": {prefix}}
+	for {
+		resp, err := s.do(ctx, http.MethodGet, s.bucketURL()+"/o?"+query.Encode(), "", nil)
+		if err != nil {
+			return err
+		}
+		var page struct {
+			Items         []gcsObject `json:"items"`
+			NextPageToken string      `json:"nextPageToken"`
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return fmt.Errorf("gcs list objects failed with status %d", resp.StatusCode)
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("gcs list objects returned an invalid page: %w", err)
+		}
+
+		for _, object := range page.Items {
+			if err := fn(object.info()); err != nil {
+				return err
+			}
+		}
+		if page.NextPageToken == "" {
+			return nil
+		}
+		query.Set("pageToken", page.NextPageToken)
+	}
+}
+
+// Check reads the metadata of the bucket
+func (s *GCS) Check(ctx context.Context) error {
+	resp, err := s.do(ctx, http.MethodGet, s.bucketURL(), "", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return fmt.Errorf("gcs bucket %s not found", s.bucket)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("gcs credentials were refused for bucket %s", s.bucket)
+	default:
+		return fmt.Errorf("gcs get bucket failed with status %d", resp.StatusCode)
+	}
+}
+
+// upload sends body as the media of the object
+func (s *GCS) upload(ctx context.Context, key, contentType string, body io.Reader) error {
+	target := s.endpoint.String() + "/upload/storage/v1/b/" + url.PathEscape(s.bucket) + "/o?" +
+		url.Values{"uploadType": {"media"}, "name": {key}}.Encode()
+	resp, err := s.do(ctx, http.MethodPost, target, contentType, body)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("gcs upload object failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *GCS) bucketURL() string {
+	return s.endpoint.String() + "/storage/v1/b/" + url.PathEscape(s.bucket)
+}
+
+// objectURL is the resource of the object, whose name is escaped slashes
+// included
+func (s *GCS) objectURL(key string) string {
+	return s.bucketURL() + "/o/" + url.PathEscape(key)
+}
+
+// do sends a request to the JSON API with the access token of the service
+// account, if any
+func (s *GCS) do(ctx context.Context, method, target, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if s.account != nil {
+		token, err := s.accessToken(ctx)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("gcs %s request failed: %w", strings.ToLower(method), err)
+	}
+	return resp, nil
+}
+
+// accessToken returns the OAuth2 token of the service account, exchanging
+// a signed JWT for a new one a minute before the last expires
+func (s *GCS) accessToken(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if s.token != "" && now.Before(s.tokenExpiry.Add(-time.Minute)) {
+		return s.token, nil
+	}
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss":   s.account.ClientEmail,
+		"scope": gcsScope,
+		"aud":   s.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	signature, err := s.sign(unsigned)
+	if err != nil {
+		return "", err
+	}
+	assertion := unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.account.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("gcs token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("gcs token request failed with status %d", resp.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil || token.AccessToken == "" {
+		return "", fmt.Errorf("gcs token request returned no access token: %v", err)
+	}
+	s.token = token.AccessToken
+	s.tokenExpiry = now.Add(time.Duration(token.ExpiresIn) * time.Second)
+	return s.token, nil
+}
+
+// presign builds a V4 signed URL of the XML API. headers are lower-cased
+// header names that the caller must send with exactly the given values.
+func (s *GCS) presign(method, key string, expires time.Duration, headers map[string]string, now time.Time) (string, error) {
+	if s.account == nil {
+		return "", ErrPresignUnsupported
+	}
+	if expires > gcsMaxPresignExpiry {
+		expires = gcsMaxPresignExpiry
+	}
+
+	now = now.UTC()
+	date := now.Format("20060102T150405Z")
+	shortDate := now.Format("20060102")
+	scope := shortDate + "/auto/storage/goog4_request"
+	host := s.endpoint.Host
+	path := "/" + s.bucket + "/" + key
+
+	signedHeaders := map[string]string{"host": host}
+	for k, v := range headers {
+		signedHeaders[strings.ToLower(k)] = v
+	}
+	headerNames := make([]string, 0, len(signedHeaders))
+	for k := range signedHeaders {
+		headerNames = append(headerNames, k)
+	}
+	sort.Strings(headerNames)
+
+	var canonicalHeaders strings.Builder
+	for _, k := range headerNames {
+		canonicalHeaders.WriteString(k + ":" + strings.TrimSpace(signedHeaders[k]) + "\n")
+	}
+
+	canonicalQuery := canonicalQueryString(map[string]string{
+		"X-Goog-Algorithm":     "GOOG4-RSA-SHA256",
+		"X-Goog-Credential":    s.account.ClientEmail + "/" + scope,
+		"X-Goog-Date":          date,
+		"X-Goog-Expires":       strconv.Itoa(int(expires.Seconds())),
+		"X-Goog-SignedHeaders": strings.Join(headerNames, ";"),
+	})
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		encodePath(path),
+		canonicalQuery,
+		canonicalHeaders.String(),
+		strings.Join(headerNames, ";"),
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"GOOG4-RSA-SHA256",
+		date,
+		scope,
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+
+	signature, err := s.sign(stringToSign)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s://%s%s?%s&X-Goog-Signature=%s", s.endpoint.Scheme, host, encodePath(path), canonicalQuery, hex.EncodeToString(signature)), nil
+}
+
+// sign signs data with the RSA key of the service account
+func (s *GCS) sign(data string) ([]byte, error) {
+	digest := sha256.Sum256([]byte(data))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.account.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign with the gcs private key: %w", err)
+	}
+	return signature, nil
+}
+
+func (o gcsObject) info() ObjectInfo {
+	size, _ := strconv.ParseInt(o.Size, 10, 64)
+	return ObjectInfo{Key: o.Name, Size: size, ContentType: o.ContentType, LastModified: o.Updated}
+}
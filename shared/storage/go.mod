@@ -0,0 +1,3 @@
+module github.com/Mir00r/X-Form-Backend/shared/storage
+
+go 1.23.0
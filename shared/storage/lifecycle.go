@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// Expire deletes the objects of backend under prefix last modified before
+// before, and returns how many it deleted. Backends without lifecycle rules
+// of their own, like local disk, rely on it to age objects out.
+func Expire(ctx context.Context, backend Backend, prefix string, before time.Time) (int, error) {
+	var expired []string
+	err := backend.List(ctx, prefix, func(object ObjectInfo) error {
+		if object.LastModified.Before(before) {
+			expired = append(expired, object.Key)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, key := range expired {
+		if err := backend.Delete(ctx, key); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
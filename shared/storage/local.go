@@ -0,0 +1,256 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Local keeps objects on the local disk. It is meant for development and
+// can't sign URLs: wrapped in a Proxy, its URLs point back at the service.
+type Local struct {
+	dir string
+}
+
+// localMeta is stored next to every object since the filesystem has no
+// place for a content type
+type localMeta struct {
+	ContentType string `json:"content_type"`
+}
+
+// Prefixes of the files written aside by the backend, which are not objects
+const (
+	localUploadPrefix = ".upload-"
+	localCheckPrefix  = ".check-"
+)
+
+// NewLocal creates a new local-disk storage rooted at dir
+func NewLocal(dir string) (*Local, error) {
+	if dir == "" {
+		dir = "./uploads"
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create upload directory: %w", err)
+	}
+	return &Local{dir: dir}, nil
+}
+
+// PresignPut is not supported by the local disk
+func (s *Local) PresignPut(ctx context.Context, key, contentType string, expires time.Duration) (string, error) {
+	return "", ErrPresignUnsupported
+}
+
+// PresignGet is not supported by the local disk
+func (s *Local) PresignGet(ctx context.Context, key string, expires time.Duration) (string, error) {
+	return "", ErrPresignUnsupported
+}
+
+// Open opens the object file for reading. The file is an io.Seeker, so
+// downloads of local objects serve range requests.
+func (s *Local) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open object: %w", err)
+	}
+	return f, nil
+}
+
+// Put writes the object file
+func (s *Local) Put(ctx context.Context, key, contentType string, body []byte) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	return s.write(path, bytes.NewReader(body), contentType)
+}
+
+// PutStream writes the object file from body. The file is written aside
+// and renamed, so a failed write leaves no object.
+func (s *Local) PutStream(ctx context.Context, key, contentType string, body io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	return s.write(path, body, contentType)
+}
+
+// Move renames an object and its metadata
+func (s *Local) Move(ctx context.Context, srcKey, dstKey string) error {
+	src, err := s.path(srcKey)
+	if err != nil {
+		return err
+	}
+	dst, err := s.path(dstKey)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return fmt.Errorf("failed to create object directory: %w", err)
+	}
+	if err := os.Rename(src, dst); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ErrObjectNotFound
+		}
+		return fmt.Errorf("failed to move object: %w", err)
+	}
+	if err := os.Rename(src+".meta", dst+".meta"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to move object metadata: %w", err)
+	}
+	return nil
+}
+
+// Stat returns metadata for a stored object
+func (s *Local) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && fi.IsDir()) {
+		return nil, ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat object: %w", err)
+	}
+	return s.info(key, path, fi), nil
+}
+
+// Delete removes an object and its metadata
+func (s *Local) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range []string{path, path + ".meta"} {
+		if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to delete object: %w", err)
+		}
+	}
+	return nil
+}
+
+// List walks the files below the storage root in lexical order, skipping
+// the metadata and the files being written
+func (s *Local) List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	root := filepath.Clean(s.dir)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if d.IsDir() {
+			// Skip the directories no key under prefix can be in
+			if key != "." && !strings.HasPrefix(key+"/", prefix) && !strings.HasPrefix(prefix, key+"/") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		name := d.Name()
+		if !strings.HasPrefix(key, prefix) || strings.HasSuffix(name, ".meta") ||
+			strings.HasPrefix(name, localUploadPrefix) || strings.HasPrefix(name, localCheckPrefix) {
+			return nil
+		}
+		fi, err := d.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			// Deleted since it was read from its directory
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return fn(*s.info(key, path, fi))
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Check writes and removes a file below the storage root
+func (s *Local) Check(ctx context.Context) error {
+	probe, err := os.CreateTemp(s.dir, localCheckPrefix+"*")
+	if err != nil {
+		return fmt.Errorf("storage directory is not writable: %w", err)
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
+
+// info describes the object file at path, with the content type of its
+// metadata
+func (s *Local) info(key, path string, fi fs.FileInfo) *ObjectInfo {
+	var meta localMeta
+	if data, err := os.ReadFile(path + ".meta"); err == nil {
+		_ = json.Unmarshal(data, &meta)
+	}
+
+	return &ObjectInfo{
+		Key:          key,
+		Size:         fi.Size(),
+		ContentType:  meta.ContentType,
+		LastModified: fi.ModTime(),
+	}
+}
+
+// write stores the body atomically along with its metadata
+func (s *Local) write(path string, body io.Reader, contentType string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create object directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), localUploadPrefix+"*")
+	if err != nil {
+		return fmt.Errorf("failed to create object: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write object: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write object: %w", err)
+	}
+
+	meta, _ := json.Marshal(localMeta{ContentType: contentType})
+	if err := os.WriteFile(path+".meta", meta, 0o644); err != nil {
+		return fmt.Errorf("failed to write object metadata: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// path maps an object key to a file below the storage root
+func (s *Local) path(key string) (string, error) {
+	cleaned := filepath.Clean("/" + key)
+	if cleaned == "/" || strings.HasSuffix(cleaned, ".meta") {
+		return "", fmt.Errorf("invalid object key: %s", key)
+	}
+	return filepath.Join(s.dir, cleaned), nil
+}
@@ -0,0 +1,175 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProxyPath is the route prefix the Proxy serves its signed URLs on
+const ProxyPath = "/api/v1/uploads"
+
+// Proxy signs the URLs its backend can't, pointing back at the service,
+// which serves them with ServeHTTP by streaming the object to or from the
+// backend. URLs the backend signs are returned as they are.
+type Proxy struct {
+	Backend
+	baseURL string
+	secret  []byte
+}
+
+// NewProxy wraps backend, signing URLs with secret below baseURL, the URL
+// the service is reached at
+func NewProxy(backend Backend, baseURL, secret string) (*Proxy, error) {
+	if secret == "" {
+		return nil, fmt.Errorf("storage proxy requires a signing secret")
+	}
+	return &Proxy{
+		Backend: backend,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		secret:  []byte(secret),
+	}, nil
+}
+
+// PresignPut returns the URL signed by the backend, or one served by the
+// proxy when the backend can't sign it
+func (p *Proxy) PresignPut(ctx context.Context, key, contentType string, expires time.Duration) (string, error) {
+	signed, err := p.Backend.PresignPut(ctx, key, contentType, expires)
+	if errors.Is(err, ErrPresignUnsupported) {
+		return p.presign(http.MethodPut, key, contentType, expires), nil
+	}
+	return signed, err
+}
+
+// PresignGet returns the URL signed by the backend, or one served by the
+// proxy when the backend can't sign it
+func (p *Proxy) PresignGet(ctx context.Context, key string, expires time.Duration) (string, error) {
+	signed, err := p.Backend.PresignGet(ctx, key, expires)
+	if errors.Is(err, ErrPresignUnsupported) {
+		return p.presign(http.MethodGet, key, "", expires), nil
+	}
+	return signed, err
+}
+
+// ServeHTTP accepts the PUT requests made with URLs from PresignPut and
+// serves the GET requests made with URLs from PresignGet. It must be
+// mounted on ProxyPath and the paths below it.
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPut:
+		p.upload(w, r)
+	case http.MethodGet, http.MethodHead:
+		p.download(w, r)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT")
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// upload streams the body of a signed upload to the backend
+func (p *Proxy) upload(w http.ResponseWriter, r *http.Request) {
+	key, ok := p.verify(w, r, http.MethodPut)
+	if !ok {
+		return
+	}
+	contentType := r.URL.Query().Get("content_type")
+	if r.Header.Get("Content-Type") != contentType {
+		writeError(w, http.StatusBadRequest, "content type does not match the signed upload URL")
+		return
+	}
+
+	if err := p.Backend.PutStream(r.Context(), key, contentType, r.Body); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// download streams the object of a signed download from the backend, with
+// range requests when the backend opens objects as an io.Seeker
+func (p *Proxy) download(w http.ResponseWriter, r *http.Request) {
+	key, ok := p.verify(w, r, http.MethodGet)
+	if !ok {
+		return
+	}
+
+	info, err := p.Backend.Stat(r.Context(), key)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "object not found")
+		return
+	}
+	body, err := p.Backend.Open(r.Context(), key)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "object not found")
+		return
+	}
+	defer body.Close()
+
+	if info.ContentType != "" {
+		w.Header().Set("Content-Type", info.ContentType)
+	}
+	if seeker, ok := body.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, "", info.LastModified, seeker)
+		return
+	}
+	w.Header().Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	if r.Method == http.MethodHead {
+		return
+	}
+	_, _ = io.Copy(w, body)
+}
+
+// verify checks the expiry and signature of a presigned request
+func (p *Proxy) verify(w http.ResponseWriter, r *http.Request, method string) (string, bool) {
+	key := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, ProxyPath), "/")
+	query := r.URL.Query()
+	expiresAt := query.Get("expires")
+
+	expires, err := strconv.ParseInt(expiresAt, 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		writeError(w, http.StatusForbidden, "signed URL has expired")
+		return "", false
+	}
+	if !hmac.Equal([]byte(query.Get("signature")), []byte(p.sign(method, key, query.Get("content_type"), expiresAt))) {
+		writeError(w, http.StatusForbidden, "invalid signature")
+		return "", false
+	}
+	return key, true
+}
+
+// presign builds a URL signed for one method on one key
+func (p *Proxy) presign(method, key, contentType string, expires time.Duration) string {
+	expiresAt := strconv.FormatInt(time.Now().Add(expires).Unix(), 10)
+
+	query := url.Values{}
+	if contentType != "" {
+		query.Set("content_type", contentType)
+	}
+	query.Set("expires", expiresAt)
+	query.Set("signature", p.sign(method, key, contentType, expiresAt))
+
+	return fmt.Sprintf("%s%s/%s?%s", p.baseURL, ProxyPath, key, query.Encode())
+}
+
+func (p *Proxy) sign(method, key, contentType, expiresAt string) string {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte(method + "\n" + key + "\n" + contentType + "\n" + expiresAt))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// writeError answers a proxied request with a JSON error, as the services do
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,375 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultCredentialTTL is how long the credentials of a tenant are used
+// before they are resolved again, picking up rotations
+const DefaultCredentialTTL = 15 * time.Minute
+
+// TenantFunc returns the tenant owning the object at key, or "" for the
+// objects of no tenant
+type TenantFunc func(ctx context.Context, key string) (string, error)
+
+// TenantConfig is the storage of a tenant in a bucket of its own. The
+// credentials are not part of it but read from the secret named
+// CredentialsSecret, a JSON object of access_key_id and secret_access_key
+// for s3, the JSON key of a service account for gcs, and of account_key for
+// azure.
+type TenantConfig struct {
+	Driver string `json:"driver"`
+	// Bucket is the bucket, or the container on Azure
+	Bucket   string `json:"bucket"`
+	Region   string `json:"region,omitempty"`
+	Endpoint string `json:"endpoint,omitempty"`
+	// ForcePathStyle addresses S3 buckets in the path of their URLs
+	ForcePathStyle bool `json:"force_path_style,omitempty"`
+	// Account is the storage account on Azure
+	Account           string `json:"account,omitempty"`
+	CredentialsSecret string `json:"credentials_secret"`
+}
+
+// s3Credentials is the secret of the credentials of an S3 bucket
+type s3Credentials struct {
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+}
+
+// azureCredentials is the secret of the credentials of an Azure container
+type azureCredentials struct {
+	AccountKey string `json:"account_key"`
+}
+
+// Validate checks the config of a tenant, without its credentials
+func (t TenantConfig) Validate() error {
+	switch t.Driver {
+	case DriverS3, DriverAzure:
+		if t.CredentialsSecret == "" {
+			return fmt.Errorf("credentials_secret is required by the %s driver", t.Driver)
+		}
+	case DriverGCS:
+		// Emulators take unauthenticated requests
+	default:
+		return fmt.Errorf("driver %q is not one of s3, gcs or azure", t.Driver)
+	}
+	if t.Bucket == "" {
+		return fmt.Errorf("bucket is required")
+	}
+	if t.Driver == DriverAzure && t.Account == "" {
+		return fmt.Errorf("account is required by the azure driver")
+	}
+	return nil
+}
+
+// Config returns the config of the backend of the tenant with the
+// credentials of secret, the value of CredentialsSecret
+func (t TenantConfig) Config(secret string) (Config, error) {
+	cfg := Config{Driver: t.Driver}
+	switch t.Driver {
+	case DriverS3:
+		var credentials s3Credentials
+		if err := json.Unmarshal([]byte(secret), &credentials); err != nil {
+			return Config{}, fmt.Errorf("s3 credentials are not a JSON object: %w", err)
+		}
+		cfg.S3Endpoint, cfg.S3Region, cfg.S3Bucket, cfg.S3ForcePathStyle = t.Endpoint, t.Region, t.Bucket, t.ForcePathStyle
+		cfg.S3AccessKeyID, cfg.S3SecretAccessKey = credentials.AccessKeyID, credentials.SecretAccessKey
+	case DriverGCS:
+		cfg.GCSEndpoint, cfg.GCSBucket, cfg.GCSCredentialsJSON = t.Endpoint, t.Bucket, secret
+	case DriverAzure:
+		var credentials azureCredentials
+		if err := json.Unmarshal([]byte(secret), &credentials); err != nil {
+			return Config{}, fmt.Errorf("azure credentials are not a JSON object: %w", err)
+		}
+		cfg.AzureEndpoint, cfg.AzureAccount, cfg.AzureContainer = t.Endpoint, t.Account, t.Bucket
+		cfg.AzureAccountKey = credentials.AccountKey
+	default:
+		return Config{}, fmt.Errorf("unsupported tenant storage driver: %s", t.Driver)
+	}
+	return cfg, nil
+}
+
+// Router stores the objects of the tenants with storage of their own in
+// their buckets, and the others with the fallback backend. Objects written
+// before a tenant got its bucket stay in the fallback, where they are still
+// read, moved and deleted from.
+type Router struct {
+	fallback Backend
+	tenants  map[string]TenantConfig
+	tenantOf TenantFunc
+	secrets  SecretResolver
+	ttl      time.Duration
+	now      func() time.Time
+	// open creates the backend of a tenant
+	open func(Config) (Backend, error)
+
+	mu       sync.Mutex
+	backends map[string]cachedBackend
+	health   map[string]string
+}
+
+type cachedBackend struct {
+	backend Backend
+	expires time.Time
+}
+
+// NewRouter creates a router storing the objects of tenants, found by
+// tenantOf, in their buckets, with the credentials secrets resolves, and
+// the others with fallback. Credentials are resolved again once
+// credentialTTL elapsed, or DefaultCredentialTTL when it is not positive.
+func NewRouter(fallback Backend, tenants map[string]TenantConfig, tenantOf TenantFunc, secrets SecretResolver, credentialTTL time.Duration) *Router {
+	if credentialTTL <= 0 {
+		credentialTTL = DefaultCredentialTTL
+	}
+	return &Router{
+		fallback: fallback,
+		tenants:  tenants,
+		tenantOf: tenantOf,
+		secrets:  secrets,
+		ttl:      credentialTTL,
+		now:      time.Now,
+		open:     New,
+		backends: make(map[string]cachedBackend),
+		health:   make(map[string]string),
+	}
+}
+
+// Tenant returns the backend of tenant, whose credentials are resolved
+// once per credential TTL
+func (r *Router) Tenant(ctx context.Context, tenant string) (Backend, error) {
+	now := r.now()
+	r.mu.Lock()
+	cached, ok := r.backends[tenant]
+	r.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.backend, nil
+	}
+
+	config, ok := r.tenants[tenant]
+	if !ok {
+		return nil, fmt.Errorf("tenant %s has no storage of its own", tenant)
+	}
+	var secret string
+	if config.CredentialsSecret != "" {
+		var err error
+		if secret, err = r.secrets.GetSecret(ctx, config.CredentialsSecret); err != nil {
+			return nil, fmt.Errorf("failed to resolve the storage credentials of tenant %s: %w", tenant, err)
+		}
+	}
+	cfg, err := config.Config(secret)
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage credentials of tenant %s: %w", tenant, err)
+	}
+	backend, err := r.open(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage of tenant %s: %w", tenant, err)
+	}
+
+	r.mu.Lock()
+	r.backends[tenant] = cachedBackend{backend: backend, expires: now.Add(r.ttl)}
+	r.mu.Unlock()
+	return backend, nil
+}
+
+// PresignPut signs the upload to the bucket of the tenant
+func (r *Router) PresignPut(ctx context.Context, key, contentType string, expires time.Duration) (string, error) {
+	backend, err := r.target(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	return backend.PresignPut(ctx, key, contentType, expires)
+}
+
+// PresignGet signs the download from the bucket of the tenant, or from the
+// fallback when the object isn't in the bucket of the tenant
+func (r *Router) PresignGet(ctx context.Context, key string, expires time.Duration) (string, error) {
+	backend, err := r.route(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if backend != nil {
+		_, err := backend.Stat(ctx, key)
+		if err == nil {
+			return backend.PresignGet(ctx, key, expires)
+		}
+		if !errors.Is(err, ErrObjectNotFound) {
+			return "", err
+		}
+	}
+	return r.fallback.PresignGet(ctx, key, expires)
+}
+
+// Open opens the object from the bucket of the tenant, or the fallback
+func (r *Router) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	backend, err := r.route(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if backend != nil {
+		body, err := backend.Open(ctx, key)
+		if !errors.Is(err, ErrObjectNotFound) {
+			return body, err
+		}
+	}
+	return r.fallback.Open(ctx, key)
+}
+
+// Put stores the object in the bucket of the tenant
+func (r *Router) Put(ctx context.Context, key, contentType string, body []byte) error {
+	backend, err := r.target(ctx, key)
+	if err != nil {
+		return err
+	}
+	return backend.Put(ctx, key, contentType, body)
+}
+
+// PutStream stores the object in the bucket of the tenant
+func (r *Router) PutStream(ctx context.Context, key, contentType string, body io.Reader) error {
+	backend, err := r.target(ctx, key)
+	if err != nil {
+		return err
+	}
+	return backend.PutStream(ctx, key, contentType, body)
+}
+
+// Move moves the object within the bucket of the tenant, or within the
+// fallback when the object isn't in the bucket of the tenant. Both keys
+// must belong to the same tenant.
+func (r *Router) Move(ctx context.Context, srcKey, dstKey string) error {
+	backend, err := r.route(ctx, srcKey)
+	if err != nil {
+		return err
+	}
+	if backend != nil {
+		err := backend.Move(ctx, srcKey, dstKey)
+		if !errors.Is(err, ErrObjectNotFound) {
+			return err
+		}
+	}
+	return r.fallback.Move(ctx, srcKey, dstKey)
+}
+
+// Stat describes the object from the bucket of the tenant, or the fallback
+func (r *Router) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	backend, err := r.route(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if backend != nil {
+		info, err := backend.Stat(ctx, key)
+		if !errors.Is(err, ErrObjectNotFound) {
+			return info, err
+		}
+	}
+	return r.fallback.Stat(ctx, key)
+}
+
+// Delete deletes the object from the bucket of the tenant and from the
+// fallback, wherever it was written
+func (r *Router) Delete(ctx context.Context, key string) error {
+	backend, err := r.route(ctx, key)
+	if err != nil {
+		return err
+	}
+	if backend != nil {
+		if err := backend.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+	return r.fallback.Delete(ctx, key)
+}
+
+// List lists the objects of the fallback, then those of the bucket of the
+// tenant owning the keys under prefix, if any
+func (r *Router) List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	backend, err := r.route(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	if err := r.fallback.List(ctx, prefix, fn); err != nil {
+		return err
+	}
+	if backend != nil {
+		return backend.List(ctx, prefix, fn)
+	}
+	return nil
+}
+
+// Check checks the fallback, whose error it returns, and the bucket of
+// every tenant, whose status Status reports: a tenant whose bucket is
+// unreachable only fails the requests for its objects
+func (r *Router) Check(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for tenant := range r.tenants {
+		wg.Add(1)
+		go func(tenant string) {
+			defer wg.Done()
+			status := "up"
+			backend, err := r.Tenant(ctx, tenant)
+			if err == nil {
+				err = backend.Check(ctx)
+			}
+			if err != nil {
+				status = err.Error()
+			}
+			r.mu.Lock()
+			r.health[tenant] = status
+			r.mu.Unlock()
+		}(tenant)
+	}
+	err := r.fallback.Check(ctx)
+	wg.Wait()
+	return err
+}
+
+// TenantStatus is the result of the last check of the bucket of a tenant
+type TenantStatus struct {
+	Tenant string `json:"tenant"`
+	// Status is up, or the error of the check
+	Status string `json:"status"`
+}
+
+// Status returns the results of the last checks of the buckets of the
+// tenants, for the details of readiness reports
+func (r *Router) Status() []TenantStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	statuses := make([]TenantStatus, 0, len(r.health))
+	for tenant, status := range r.health {
+		statuses = append(statuses, TenantStatus{Tenant: tenant, Status: status})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Tenant < statuses[j].Tenant })
+	return statuses
+}
+
+// route returns the backend of the tenant owning key, or nil when the
+// object belongs to no tenant with storage of its own
+func (r *Router) route(ctx context.Context, key string) (Backend, error) {
+	if len(r.tenants) == 0 {
+		return nil, nil
+	}
+	tenant, err := r.tenantOf(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the tenant of %s: %w", key, err)
+	}
+	if _, ok := r.tenants[tenant]; !ok {
+		return nil, nil
+	}
+	return r.Tenant(ctx, tenant)
+}
+
+// target returns the backend objects are written to at key
+func (r *Router) target(ctx context.Context, key string) (Backend, error) {
+	backend, err := r.route(ctx, key)
+	if err != nil || backend != nil {
+		return backend, err
+	}
+	return r.fallback, nil
+}
@@ -0,0 +1,241 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// countingSecrets resolves every key to its value in secrets, counting the
+// resolutions
+type countingSecrets struct {
+	secrets  map[string]string
+	resolved int
+}
+
+func (s *countingSecrets) GetSecret(ctx context.Context, key string) (string, error) {
+	s.resolved++
+	value, ok := s.secrets[key]
+	if !ok {
+		return "", errors.New("secret not found")
+	}
+	return value, nil
+}
+
+// brokenBackend fails every check
+type brokenBackend struct{ Backend }
+
+func (brokenBackend) Check(ctx context.Context) error { return errors.New("bucket not found") }
+
+// tenantOfPrefix owns the keys tenant/... to tenant
+func tenantOfPrefix(ctx context.Context, key string) (string, error) {
+	tenant, _, _ := strings.Cut(key, "/")
+	if tenant == "unknown" {
+		return "", errors.New("form not found")
+	}
+	return tenant, nil
+}
+
+func newTestRouter(t *testing.T) (*Router, Backend, map[string]Backend, *countingSecrets) {
+	t.Helper()
+	fallback, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets := &countingSecrets{secrets: map[string]string{
+		"storage/acme":   `{"access_key_id":"AKIA","secret_access_key":"s3cret"}`,
+		"storage/globex": `{"account_key":"a2V5"}`,
+	}}
+	router := NewRouter(fallback, map[string]TenantConfig{
+		"acme":   {Driver: DriverS3, Bucket: "acme-eu", Region: "eu-west-1", CredentialsSecret: "storage/acme"},
+		"globex": {Driver: DriverAzure, Bucket: "forms", Account: "globex", CredentialsSecret: "storage/globex"},
+	}, tenantOfPrefix, secrets, time.Hour)
+
+	tenants := make(map[string]Backend)
+	router.open = func(cfg Config) (Backend, error) {
+		var tenant string
+		switch {
+		case cfg.Driver == DriverS3 && cfg.S3Bucket == "acme-eu" && cfg.S3AccessKeyID == "AKIA" && cfg.S3SecretAccessKey == "s3cret":
+			tenant = "acme"
+		case cfg.Driver == DriverAzure && cfg.AzureContainer == "forms" && cfg.AzureAccountKey == "a2V5":
+			tenant = "globex"
+		default:
+			t.Fatalf("unexpected tenant config: %+v", cfg)
+		}
+		if tenants[tenant] == nil {
+			backend, err := NewLocal(t.TempDir())
+			if err != nil {
+				return nil, err
+			}
+			tenants[tenant] = backend
+		}
+		return tenants[tenant], nil
+	}
+	return router, fallback, tenants, secrets
+}
+
+func TestRouterStoresObjectsOfTenantsInTheirBuckets(t *testing.T) {
+	router, fallback, tenants, _ := newTestRouter(t)
+	ctx := context.Background()
+
+	if err := router.Put(ctx, "acme/receipt.pdf", "application/pdf", []byte("acme")); err != nil {
+		t.Fatal(err)
+	}
+	if err := router.Put(ctx, "initech/receipt.pdf", "application/pdf", []byte("initech")); err != nil {
+		t.Fatal(err)
+	}
+	expectObject(t, tenants["acme"], "acme/receipt.pdf", []byte("acme"))
+	expectObject(t, fallback, "initech/receipt.pdf", []byte("initech"))
+	if _, err := fallback.Stat(ctx, "acme/receipt.pdf"); !errors.Is(err, ErrObjectNotFound) {
+		t.Fatalf("object of a tenant stored in the fallback: %v", err)
+	}
+	expectObject(t, router, "acme/receipt.pdf", []byte("acme"))
+	expectObject(t, router, "initech/receipt.pdf", []byte("initech"))
+
+	if err := router.Move(ctx, "acme/receipt.pdf", "acme/archived.pdf"); err != nil {
+		t.Fatal(err)
+	}
+	expectObject(t, tenants["acme"], "acme/archived.pdf", []byte("acme"))
+
+	if keys := listKeys(t, router, "acme/"); strings.Join(keys, ",") != "acme/archived.pdf" {
+		t.Fatalf("unexpected listed keys: %v", keys)
+	}
+	if err := router.Delete(ctx, "acme/archived.pdf"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := router.Stat(ctx, "acme/archived.pdf"); !errors.Is(err, ErrObjectNotFound) {
+		t.Fatalf("deleted object still found: %v", err)
+	}
+}
+
+func TestRouterReadsObjectsWrittenBeforeTheBucketOfTheTenant(t *testing.T) {
+	router, fallback, _, _ := newTestRouter(t)
+	ctx := context.Background()
+
+	if err := fallback.Put(ctx, "acme/old.pdf", "application/pdf", []byte("old")); err != nil {
+		t.Fatal(err)
+	}
+	expectObject(t, router, "acme/old.pdf", []byte("old"))
+	if _, err := router.Stat(ctx, "acme/old.pdf"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := router.PresignGet(ctx, "acme/old.pdf", time.Minute); !errors.Is(err, ErrPresignUnsupported) {
+		t.Fatalf("presign of an object in the fallback returned %v", err)
+	}
+	if err := router.Move(ctx, "acme/old.pdf", "acme/older.pdf"); err != nil {
+		t.Fatal(err)
+	}
+	expectObject(t, fallback, "acme/older.pdf", []byte("old"))
+
+	if keys := listKeys(t, router, "acme/"); strings.Join(keys, ",") != "acme/older.pdf" {
+		t.Fatalf("unexpected listed keys: %v", keys)
+	}
+	if err := router.Delete(ctx, "acme/older.pdf"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fallback.Stat(ctx, "acme/older.pdf"); !errors.Is(err, ErrObjectNotFound) {
+		t.Fatalf("object in the fallback not deleted: %v", err)
+	}
+}
+
+func TestRouterFailsWhenTheTenantIsUnknown(t *testing.T) {
+	router, fallback, _, _ := newTestRouter(t)
+	ctx := context.Background()
+
+	if err := router.Put(ctx, "unknown/receipt.pdf", "application/pdf", []byte("x")); err == nil {
+		t.Fatal("put without a tenant succeeded")
+	}
+	if _, err := fallback.Stat(ctx, "unknown/receipt.pdf"); !errors.Is(err, ErrObjectNotFound) {
+		t.Fatalf("object without a tenant stored in the fallback: %v", err)
+	}
+}
+
+func TestRouterResolvesCredentialsOncePerTTL(t *testing.T) {
+	router, _, _, secrets := newTestRouter(t)
+	ctx := context.Background()
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	router.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if _, err := router.Stat(ctx, "acme/missing.pdf"); !errors.Is(err, ErrObjectNotFound) {
+			t.Fatal(err)
+		}
+	}
+	if secrets.resolved != 1 {
+		t.Fatalf("credentials resolved %d times, want once", secrets.resolved)
+	}
+	now = now.Add(2 * time.Hour)
+	if _, err := router.Stat(ctx, "acme/missing.pdf"); !errors.Is(err, ErrObjectNotFound) {
+		t.Fatal(err)
+	}
+	if secrets.resolved != 2 {
+		t.Fatalf("credentials resolved %d times after the TTL, want twice", secrets.resolved)
+	}
+}
+
+func TestRouterWithoutTenantsUsesTheFallback(t *testing.T) {
+	fallback, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := NewRouter(fallback, nil, func(ctx context.Context, key string) (string, error) {
+		t.Fatal("tenant looked up without tenants")
+		return "", nil
+	}, nil, 0)
+	if err := router.Put(context.Background(), "acme/receipt.pdf", "application/pdf", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	expectObject(t, fallback, "acme/receipt.pdf", []byte("x"))
+}
+
+func TestRouterCheckReportsTenantsApart(t *testing.T) {
+	router, _, _, secrets := newTestRouter(t)
+	open := router.open
+	router.open = func(cfg Config) (Backend, error) {
+		backend, err := open(cfg)
+		if cfg.Driver == DriverAzure {
+			return brokenBackend{backend}, err
+		}
+		return backend, err
+	}
+	delete(secrets.secrets, "storage/acme")
+
+	if err := router.Check(context.Background()); err != nil {
+		t.Fatalf("check failed with the fallback up: %v", err)
+	}
+	status := router.Status()
+	if len(status) != 2 || status[0].Tenant != "acme" || status[1].Tenant != "globex" {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+	if !strings.Contains(status[0].Status, "failed to resolve the storage credentials") {
+		t.Fatalf("unexpected status of acme: %s", status[0].Status)
+	}
+	if status[1].Status != "bucket not found" {
+		t.Fatalf("unexpected status of globex: %s", status[1].Status)
+	}
+}
+
+func TestTenantConfigValidate(t *testing.T) {
+	cases := []struct {
+		config TenantConfig
+		valid  bool
+	}{
+		{TenantConfig{Driver: DriverS3, Bucket: "b", CredentialsSecret: "s"}, true},
+		{TenantConfig{Driver: DriverS3, Bucket: "b"}, false},
+		{TenantConfig{Driver: DriverGCS, Bucket: "b"}, true},
+		{TenantConfig{Driver: DriverAzure, Bucket: "b", CredentialsSecret: "s"}, false},
+		{TenantConfig{Driver: DriverAzure, Bucket: "b", Account: "a", CredentialsSecret: "s"}, true},
+		{TenantConfig{Driver: DriverLocal, Bucket: "b"}, false},
+		{TenantConfig{Driver: DriverS3, CredentialsSecret: "s"}, false},
+	}
+	for _, c := range cases {
+		if err := c.config.Validate(); (err == nil) != c.valid {
+			t.Errorf("Validate(%+v) = %v, want valid %v", c.config, err, c.valid)
+		}
+	}
+	if _, err := (TenantConfig{Driver: DriverS3, Bucket: "b"}).Config("not json"); err == nil {
+		t.Error("invalid s3 credentials accepted")
+	}
+}
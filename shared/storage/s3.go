@@ -0,0 +1,369 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3 talks to any S3-compatible store (AWS S3, MinIO, R2...) using
+// SigV4 query-string signing, so no SDK is required
+type S3 struct {
+	endpoint        *url.URL
+	region          string
+	bucket          string
+	accessKeyID     string
+	secretAccessKey string
+	forcePathStyle  bool
+	client          *http.Client
+}
+
+// NewS3 creates a new S3-compatible storage client
+func NewS3(endpoint, region, bucket, accessKeyID, secretAccessKey string, forcePathStyle bool) (*S3, error) {
+	if bucket == "" {
+		return nil, fmt.Errorf("s3 bucket is required")
+	}
+	if accessKeyID == "" || secretAccessKey == "" {
+		return nil, fmt.Errorf("s3 credentials are required")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint: %w", err)
+	}
+
+	return &S3{
+		endpoint:        u,
+		region:          region,
+		bucket:          bucket,
+		accessKeyID:     accessKeyID,
+		secretAccessKey: secretAccessKey,
+		forcePathStyle:  forcePathStyle,
+		client:          &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// PresignPut returns a pre-signed PUT URL bound to the given content type
+func (s *S3) PresignPut(ctx context.Context, key, contentType string, expires time.Duration) (string, error) {
+	return s.presign(http.MethodPut, key, expires, map[string]string{"content-type": contentType}, nil, time.Now())
+}
+
+// PresignGet returns a pre-signed GET URL for the object
+func (s *S3) PresignGet(ctx context.Context, key string, expires time.Duration) (string, error) {
+	return s.presign(http.MethodGet, key, expires, nil, nil, time.Now())
+}
+
+// Open issues a signed GET request and returns the object body
+func (s *S3) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ErrObjectNotFound
+	default:
+		resp.Body.Close()
+		return nil, fmt.Errorf("s3 get object failed with status %d", resp.StatusCode)
+	}
+}
+
+// Put uploads the object with a signed PUT request
+func (s *S3) Put(ctx context.Context, key, contentType string, body []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, key, nil, map[string]string{"content-type": contentType}, body)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("s3 put object failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Move copies the object server-side and deletes the source
+func (s *S3) Move(ctx context.Context, srcKey, dstKey string) error {
+	resp, err := s.do(ctx, http.MethodPut, dstKey, nil, map[string]string{
+		"x-amz-copy-source": uriEncode("/"+s.bucket+"/"+srcKey, false),
+	}, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrObjectNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("s3 copy object failed with status %d", resp.StatusCode)
+	}
+
+	return s.Delete(ctx, srcKey)
+}
+
+// Stat issues a signed HEAD request for the object
+func (s *S3) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	resp, err := s.do(ctx, http.MethodHead, key, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrObjectNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("s3 head object failed with status %d", resp.StatusCode)
+	}
+
+	size, _ := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	modified, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+
+	return &ObjectInfo{
+		Key:          key,
+		Size:         size,
+		ContentType:  resp.Header.Get("Content-Type"),
+		LastModified: modified,
+	}, nil
+}
+
+// Delete issues a signed DELETE request for the object
+func (s *S3) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("s3 delete object failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// s3ListBucketResult is the response of ListObjectsV2
+type s3ListBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		LastModified time.Time `xml:"LastModified"`
+		Size         int64     `xml:"Size"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List pages through the objects with ListObjectsV2, which doesn't return
+// their content type
+func (s *S3) List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	params := map[string]string{"list-type": "2", "prefix": prefix}
+	for {
+		resp, err := s.do(ctx, http.MethodGet, "", params, nil, nil)
+		if err != nil {
+			return err
+		}
+		var page s3ListBucketResult
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return fmt.Errorf("s3 list objects failed with status %d", resp.StatusCode)
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("s3 list objects returned an invalid page: %w", err)
+		}
+
+		for _, object := range page.Contents {
+			if err := fn(ObjectInfo{Key: object.Key, Size: object.Size, LastModified: object.LastModified}); err != nil {
+				return err
+			}
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return nil
+		}
+		params["continuation-token"] = page.NextContinuationToken
+	}
+}
+
+// Check issues a signed HEAD request for the bucket
+func (s *S3) Check(ctx context.Context) error {
+	resp, err := s.do(ctx, http.MethodHead, "", nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return fmt.Errorf("s3 bucket %s not found", s.bucket)
+	case http.StatusForbidden:
+		return fmt.Errorf("s3 credentials were refused for bucket %s", s.bucket)
+	default:
+		return fmt.Errorf("s3 head bucket failed with status %d", resp.StatusCode)
+	}
+}
+
+// do sends a request authenticated with a short-lived pre-signed URL.
+// params are the query parameters of the request, signed with it.
+func (s *S3) do(ctx context.Context, method, key string, params, headers map[string]string, body []byte) (*http.Response, error) {
+	signed, err := s.presign(method, key, time.Minute, headers, params, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, signed, reader)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 %s request failed: %w", strings.ToLower(method), err)
+	}
+	return resp, nil
+}
+
+// presign builds a SigV4 query-signed URL. headers are lower-cased header
+// names that the caller must send with exactly the given values; params are
+// query parameters added to the URL.
+func (s *S3) presign(method, key string, expires time.Duration, headers, params map[string]string, now time.Time) (string, error) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	shortDate := now.Format("20060102")
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", shortDate, s.region)
+
+	host := s.endpoint.Host
+	path := "/" + key
+	if s.forcePathStyle {
+		path = "/" + s.bucket + "/" + key
+	} else {
+		host = s.bucket + "." + host
+	}
+
+	signedHeaders := map[string]string{"host": host}
+	for k, v := range headers {
+		signedHeaders[strings.ToLower(k)] = v
+	}
+	headerNames := make([]string, 0, len(signedHeaders))
+	for k := range signedHeaders {
+		headerNames = append(headerNames, k)
+	}
+	sort.Strings(headerNames)
+
+	var canonicalHeaders strings.Builder
+	for _, k := range headerNames {
+		canonicalHeaders.WriteString(k + ":" + strings.TrimSpace(signedHeaders[k]) + "\n")
+	}
+
+	query := map[string]string{
+		"X-Amz-Algorithm":     "AWS4-HMAC-SHA256",
+		"X-Amz-Credential":    s.accessKeyID + "/" + scope,
+		"X-Amz-Date":          amzDate,
+		"X-Amz-Expires":       strconv.Itoa(int(expires.Seconds())),
+		"X-Amz-SignedHeaders": strings.Join(headerNames, ";"),
+	}
+	for k, v := range params {
+		query[k] = v
+	}
+	canonicalQuery := canonicalQueryString(query)
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		encodePath(path),
+		canonicalQuery,
+		canonicalHeaders.String(),
+		strings.Join(headerNames, ";"),
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.secretAccessKey), shortDate)
+	signingKey = hmacSHA256(signingKey, s.region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	return fmt.Sprintf("%s://%s%s?%s&X-Amz-Signature=%s", s.endpoint.Scheme, host, encodePath(path), canonicalQuery, signature), nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQueryString sorts and encodes query parameters as SigV4 requires
+func canonicalQueryString(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, uriEncode(k, true)+"="+uriEncode(params[k], true))
+	}
+	return strings.Join(pairs, "&")
+}
+
+// encodePath URI-encodes every segment of an object path, keeping slashes
+func encodePath(path string) string {
+	return uriEncode(path, false)
+}
+
+// uriEncode implements the SigV4 flavour of percent-encoding: everything
+// except unreserved characters is escaped
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// s3PartSize is the size of the parts of streamed uploads, above the 5 MiB
+// minimum of S3. It is the memory a streamed upload holds.
+const s3PartSize = 8 << 20
+
+type s3InitiateMultipartUpload struct {
+	UploadID string `xml:"UploadId"`
+}
+
+type s3CompletedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type s3CompleteMultipartUpload struct {
+	XMLName xml.Name          `xml:"CompleteMultipartUpload"`
+	Parts   []s3CompletedPart `xml:"Part"`
+}
+
+// PutStream uploads the object part by part with a multipart upload, so
+// only one part is held in memory. Objects smaller than a part are sent
+// with a single PUT. A failure aborts the upload.
+func (s *S3) PutStream(ctx context.Context, key, contentType string, body io.Reader) error {
+	part := make([]byte, s3PartSize)
+	n, err := io.ReadFull(body, part)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return s.Put(ctx, key, contentType, part[:n])
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read object: %w", err)
+	}
+
+	uploadID, err := s.initiateMultipart(ctx, key, contentType)
+	if err != nil {
+		return err
+	}
+	if err := s.uploadParts(ctx, key, uploadID, part, body); err != nil {
+		if abortErr := s.abortMultipart(context.WithoutCancel(ctx), key, uploadID); abortErr != nil {
+			log.Printf("Failed to abort multipart upload of %s: %v", key, abortErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// uploadParts sends the first part, already read, then the rest of body,
+// and completes the upload
+func (s *S3) uploadParts(ctx context.Context, key, uploadID string, part []byte, body io.Reader) error {
+	var parts []s3CompletedPart
+	for number := 1; len(part) > 0; number++ {
+		etag, err := s.uploadPart(ctx, key, uploadID, number, part)
+		if err != nil {
+			return err
+		}
+		parts = append(parts, s3CompletedPart{PartNumber: number, ETag: etag})
+
+		part = part[:cap(part)]
+		n, err := io.ReadFull(body, part)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("failed to read object: %w", err)
+		}
+		part = part[:n]
+	}
+	return s.completeMultipart(ctx, key, uploadID, parts)
+}
+
+func (s *S3) initiateMultipart(ctx context.Context, key, contentType string) (string, error) {
+	resp, err := s.do(ctx, http.MethodPost, key, map[string]string{"uploads": ""}, map[string]string{"content-type": contentType}, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("s3 create multipart upload failed with status %d", resp.StatusCode)
+	}
+
+	var initiated s3InitiateMultipartUpload
+	if err := xml.NewDecoder(resp.Body).Decode(&initiated); err != nil || initiated.UploadID == "" {
+		return "", fmt.Errorf("s3 create multipart upload returned no upload ID: %v", err)
+	}
+	return initiated.UploadID, nil
+}
+
+func (s *S3) uploadPart(ctx context.Context, key, uploadID string, number int, part []byte) (string, error) {
+	resp, err := s.do(ctx, http.MethodPut, key, map[string]string{
+		"partNumber": strconv.Itoa(number),
+		"uploadId":   uploadID,
+	}, nil, part)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("s3 upload part %d failed with status %d", number, resp.StatusCode)
+	}
+	return resp.Header.Get("ETag"), nil
+}
+
+func (s *S3) completeMultipart(ctx context.Context, key, uploadID string, parts []s3CompletedPart) error {
+	body, err := xml.Marshal(s3CompleteMultipartUpload{Parts: parts})
+	if err != nil {
+		return fmt.Errorf("failed to encode multipart upload: %w", err)
+	}
+	resp, err := s.do(ctx, http.MethodPost, key, map[string]string{"uploadId": uploadID}, nil, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// S3 may report a failed completion in the body of a 200
+	result, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return fmt.Errorf("s3 complete multipart upload failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || bytes.Contains(result, []byte("<Error>")) {
+		return fmt.Errorf("s3 complete multipart upload failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *S3) abortMultipart(ctx context.Context, key, uploadID string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, map[string]string{"uploadId": uploadID}, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("s3 abort multipart upload failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
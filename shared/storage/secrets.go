@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SecretResolver resolves the keys of the secrets holding the credentials
+// of tenant storage to their values. The providers of shared/secrets
+// satisfy it; the vault, aws-secrets and aws-ssm resolvers read the secrets
+// they store without their SDKs.
+type SecretResolver interface {
+	GetSecret(ctx context.Context, key string) (string, error)
+}
+
+// Providers of NewSecretResolver, named as in shared/secrets
+const (
+	SecretsEnvironment = "environment"
+	SecretsFile        = "file"
+	SecretsVault       = "vault"
+	SecretsAWS         = "aws-secrets"
+	SecretsAWSSSM      = "aws-ssm"
+)
+
+// SecretsConfig selects and configures the provider of NewSecretResolver
+type SecretsConfig struct {
+	Provider string // "environment", "file", "vault", "aws-secrets" or "aws-ssm"
+	// Prefix is put before the environment variables of the environment
+	// provider, Dir is the directory of the file provider
+	Prefix string
+	Dir    string
+
+	// VaultMountPath is the mount of the KV engine, secret by default, and
+	// VaultKVVersion its version, 2 by default
+	VaultAddress   string
+	VaultToken     string
+	VaultNamespace string
+	VaultMountPath string
+	VaultKVVersion int
+
+	// AWSEndpoint is empty for the regional endpoint of the service, and
+	// AWSSSMPath the path the parameters of aws-ssm are kept under
+	AWSRegion          string
+	AWSEndpoint        string
+	AWSAccessKeyID     string
+	AWSSecretAccessKey string
+	AWSSessionToken    string
+	AWSSSMPath         string
+}
+
+// NewSecretResolver creates the resolver of cfg.Provider: environment reads
+// the secrets from environment variables named after their keys behind
+// cfg.Prefix, file from the files of cfg.Dir, vault from a KV engine of
+// Vault, aws-secrets from AWS Secrets Manager and aws-ssm from the
+// parameters of AWS Systems Manager
+func NewSecretResolver(cfg SecretsConfig) (SecretResolver, error) {
+	switch cfg.Provider {
+	case "", SecretsEnvironment:
+		return &EnvironmentSecrets{Prefix: cfg.Prefix}, nil
+	case SecretsFile:
+		if cfg.Dir == "" {
+			return nil, fmt.Errorf("secrets directory is required by the file provider")
+		}
+		return &FileSecrets{Dir: cfg.Dir}, nil
+	case SecretsVault:
+		vault, err := NewVaultSecrets(cfg.VaultAddress, cfg.VaultToken, cfg.VaultNamespace, cfg.VaultMountPath, cfg.VaultKVVersion)
+		if err != nil {
+			return nil, err
+		}
+		return vault, nil
+	case SecretsAWS:
+		secrets, err := NewAWSSecrets(cfg.AWSEndpoint, cfg.AWSRegion, cfg.AWSAccessKeyID, cfg.AWSSecretAccessKey, cfg.AWSSessionToken)
+		if err != nil {
+			return nil, err
+		}
+		return secrets, nil
+	case SecretsAWSSSM:
+		parameters, err := NewAWSParameters(cfg.AWSEndpoint, cfg.AWSRegion, cfg.AWSAccessKeyID, cfg.AWSSecretAccessKey, cfg.AWSSessionToken, cfg.AWSSSMPath)
+		if err != nil {
+			return nil, err
+		}
+		return parameters, nil
+	default:
+		return nil, fmt.Errorf("unsupported secrets provider %q", cfg.Provider)
+	}
+}
+
+// EnvironmentSecrets resolves secrets from environment variables: key
+// storage/acme is read from Prefix+STORAGE_ACME
+type EnvironmentSecrets struct {
+	Prefix string
+}
+
+// GetSecret returns the secret of key
+func (e *EnvironmentSecrets) GetSecret(ctx context.Context, key string) (string, error) {
+	name := e.Prefix + strings.ToUpper(strings.NewReplacer("/", "_", ".", "_", "-", "_").Replace(key))
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("secret %s not found in environment variable %s", key, name)
+	}
+	return value, nil
+}
+
+// FileSecrets resolves secrets from files, one per key under Dir as mounted
+// by Kubernetes secrets; the trailing newline of a file is dropped
+type FileSecrets struct {
+	Dir string
+}
+
+// GetSecret returns the secret of key
+func (f *FileSecrets) GetSecret(ctx context.Context, key string) (string, error) {
+	for _, part := range strings.Split(key, "/") {
+		if part == "." || part == ".." {
+			return "", fmt.Errorf("secret key %s must not leave the secrets directory", key)
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(f.Dir, filepath.FromSlash(key)))
+	if err != nil {
+		return "", fmt.Errorf("secret %s not found: %w", key, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
@@ -0,0 +1,132 @@
+// Package storage is the object storage of the services: uploaded files,
+// export artifacts, receipts, archives and event bus claim checks. Every backend implements Backend:
+// S3 and the stores compatible with it, Google Cloud Storage, Azure Blob
+// Storage and the local disk. Backends talk to the REST APIs of the stores
+// with the standard library only, so no cloud SDK is required.
+//
+// Uploads and downloads never pass through the services: clients receive a
+// pre-signed URL and send or fetch the object straight from the store.
+// Backends that can't sign a URL, such as the local disk, are wrapped in a
+// Proxy, whose signed URLs point back at the service streaming the object.
+//
+// A Router stores the objects of tenants, such as the organizations of the
+// form service, in buckets of their own, whose credentials are resolved
+// through a SecretResolver.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrObjectNotFound is returned when the requested object does not exist
+var ErrObjectNotFound = errors.New("object not found")
+
+// ErrPresignUnsupported is returned by the backends that can't sign a URL
+// for a request; a Proxy signs one of its own instead
+var ErrPresignUnsupported = errors.New("pre-signed URLs are not supported")
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+}
+
+// Backend abstracts an object store
+type Backend interface {
+	// PresignPut returns a URL the client can PUT the object to until expires elapses.
+	// The upload must be sent with the given Content-Type header.
+	PresignPut(ctx context.Context, key, contentType string, expires time.Duration) (string, error)
+	// PresignGet returns a URL the object can be downloaded from until expires elapses
+	PresignGet(ctx context.Context, key string, expires time.Duration) (string, error)
+	// Open streams the object contents; callers must close the reader
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Put stores an object written by the service itself
+	Put(ctx context.Context, key, contentType string, body []byte) error
+	// PutStream stores an object read from body until EOF, without holding
+	// it in memory. An error reading body fails the upload, storing nothing.
+	PutStream(ctx context.Context, key, contentType string, body io.Reader) error
+	// Move relocates an object to a new key
+	Move(ctx context.Context, srcKey, dstKey string) error
+	// Stat returns metadata for an uploaded object or ErrObjectNotFound
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	// Delete removes an object; deleting a missing object is not an error
+	Delete(ctx context.Context, key string) error
+	// List calls fn with every object whose key starts with prefix, until
+	// fn returns an error, which List returns
+	List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error
+	// Check returns an error unless the store is reachable with the
+	// credentials of the backend, for readiness probes
+	Check(ctx context.Context) error
+}
+
+// Drivers of Config
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+	DriverGCS   = "gcs"
+	DriverAzure = "azure"
+)
+
+// Config holds object storage settings
+type Config struct {
+	Driver   string // "local", "s3", "gcs" or "azure"
+	LocalDir string
+	// PublicBaseURL and SigningSecret sign the URLs of the Proxy
+	PublicBaseURL string
+	SigningSecret string
+
+	S3Endpoint        string
+	S3Region          string
+	S3Bucket          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	S3ForcePathStyle  bool
+
+	// GCSEndpoint is empty for Google Cloud Storage, or the URL of an
+	// emulator such as fake-gcs-server
+	GCSEndpoint string
+	GCSBucket   string
+	// GCSCredentialsJSON is the JSON key of a service account. Requests
+	// are sent unauthenticated without it, as emulators take them.
+	GCSCredentialsJSON string
+
+	// AzureEndpoint is empty for https://{account}.blob.core.windows.net,
+	// or the URL of the account on Azurite
+	AzureEndpoint   string
+	AzureAccount    string
+	AzureAccountKey string
+	AzureContainer  string
+}
+
+// New creates the storage backend selected by cfg.Driver. Services wrap it
+// in a Proxy for the backends that can't sign every URL.
+func New(cfg Config) (Backend, error) {
+	switch cfg.Driver {
+	case "", DriverLocal:
+		return NewLocal(cfg.LocalDir)
+	case DriverS3:
+		return NewS3(cfg.S3Endpoint, cfg.S3Region, cfg.S3Bucket, cfg.S3AccessKeyID, cfg.S3SecretAccessKey, cfg.S3ForcePathStyle)
+	case DriverGCS:
+		return NewGCS(cfg.GCSEndpoint, cfg.GCSBucket, cfg.GCSCredentialsJSON)
+	case DriverAzure:
+		return NewAzure(cfg.AzureEndpoint, cfg.AzureAccount, cfg.AzureAccountKey, cfg.AzureContainer)
+	default:
+		return nil, fmt.Errorf("unsupported storage driver: %s", cfg.Driver)
+	}
+}
+
+// NewLocalStorage creates a local-disk backend rooted at dir whose URLs are
+// signed by a Proxy serving them from baseURL, as in development
+func NewLocalStorage(dir, baseURL, secret string) (*Proxy, error) {
+	local, err := NewLocal(dir)
+	if err != nil {
+		return nil, err
+	}
+	return NewProxy(local, baseURL, secret)
+}
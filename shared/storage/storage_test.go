@@ -0,0 +1,465 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// azuriteKey is the well-known key of the devstoreaccount1 account of Azurite
+const azuriteKey = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tOXK/K1SZFPTOtr/KBHBeksoGMGw=="
+
+// onlyReader hides the other interfaces of a reader, such as the size of a
+// bytes.Reader, as a request body does
+type onlyReader struct{ io.Reader }
+
+// testBackend runs the operations every backend implements against backend,
+// below a prefix of its own
+func testBackend(t *testing.T, backend Backend) {
+	t.Helper()
+	ctx := context.Background()
+	prefix := fmt.Sprintf("conformance/%d/", time.Now().UnixNano())
+
+	if err := backend.Check(ctx); err != nil {
+		t.Fatalf("check failed: %v", err)
+	}
+	if _, err := backend.Stat(ctx, prefix+"missing.txt"); !errors.Is(err, ErrObjectNotFound) {
+		t.Fatalf("stat of a missing object returned %v, want ErrObjectNotFound", err)
+	}
+	if _, err := backend.Open(ctx, prefix+"missing.txt"); !errors.Is(err, ErrObjectNotFound) {
+		t.Fatalf("open of a missing object returned %v, want ErrObjectNotFound", err)
+	}
+	if err := backend.Delete(ctx, prefix+"missing.txt"); err != nil {
+		t.Fatalf("delete of a missing object failed: %v", err)
+	}
+
+	if err := backend.Put(ctx, prefix+"a.txt", "text/plain", []byte("hello")); err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+	info, err := backend.Stat(ctx, prefix+"a.txt")
+	if err != nil {
+		t.Fatalf("stat failed: %v", err)
+	}
+	if info.Key != prefix+"a.txt" || info.Size != 5 || !strings.HasPrefix(info.ContentType, "text/plain") {
+		t.Fatalf("unexpected object info: %+v", info)
+	}
+	expectObject(t, backend, prefix+"a.txt", []byte("hello"))
+
+	// Larger than a part or block, so multipart and block uploads are used
+	streamed := bytes.Repeat([]byte("0123456789abcdef"), (9<<20)/16+1)
+	if err := backend.PutStream(ctx, prefix+"b.bin", "application/octet-stream", onlyReader{bytes.NewReader(streamed)}); err != nil {
+		t.Fatalf("put stream failed: %v", err)
+	}
+	expectObject(t, backend, prefix+"b.bin", streamed)
+	failing := io.MultiReader(strings.NewReader("partial"), failingReader{})
+	if err := backend.PutStream(ctx, prefix+"failed.bin", "application/octet-stream", failing); err == nil {
+		t.Fatal("put stream of a failing body succeeded")
+	}
+	if _, err := backend.Stat(ctx, prefix+"failed.bin"); !errors.Is(err, ErrObjectNotFound) {
+		t.Fatalf("failed put stream stored an object: %v", err)
+	}
+
+	if err := backend.Move(ctx, prefix+"b.bin", prefix+"moved/c.bin"); err != nil {
+		t.Fatalf("move failed: %v", err)
+	}
+	if _, err := backend.Stat(ctx, prefix+"b.bin"); !errors.Is(err, ErrObjectNotFound) {
+		t.Fatalf("moved object still at its source: %v", err)
+	}
+	expectObject(t, backend, prefix+"moved/c.bin", streamed)
+
+	signed, err := backend.PresignGet(ctx, prefix+"a.txt", time.Minute)
+	switch {
+	case errors.Is(err, ErrPresignUnsupported):
+	case err != nil:
+		t.Fatalf("presign get failed: %v", err)
+	default:
+		resp, err := http.Get(signed)
+		if err != nil {
+			t.Fatalf("signed download failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || string(body) != "hello" {
+			t.Fatalf("signed download returned %d %q", resp.StatusCode, body)
+		}
+	}
+
+	if keys := listKeys(t, backend, prefix); strings.Join(keys, ",") != prefix+"a.txt,"+prefix+"moved/c.bin" {
+		t.Fatalf("unexpected listed keys: %v", keys)
+	}
+	stop := errors.New("stop")
+	if err := backend.List(ctx, prefix, func(ObjectInfo) error { return stop }); !errors.Is(err, stop) {
+		t.Fatalf("list returned %v, want the error of fn", err)
+	}
+
+	if deleted, err := Expire(ctx, backend, prefix, time.Now().Add(-time.Hour)); err != nil || deleted != 0 {
+		t.Fatalf("expire of recent objects deleted %d: %v", deleted, err)
+	}
+	if deleted, err := Expire(ctx, backend, prefix, time.Now().Add(time.Hour)); err != nil || deleted != 2 {
+		t.Fatalf("expire deleted %d objects, want 2: %v", deleted, err)
+	}
+	if keys := listKeys(t, backend, prefix); len(keys) != 0 {
+		t.Fatalf("expired objects still listed: %v", keys)
+	}
+}
+
+// failingReader fails every read
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) { return 0, errors.New("connection reset") }
+
+func expectObject(t *testing.T, backend Backend, key string, want []byte) {
+	t.Helper()
+	body, err := backend.Open(context.Background(), key)
+	if err != nil {
+		t.Fatalf("open %s failed: %v", key, err)
+	}
+	defer body.Close()
+	got, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("read %s failed: %v", key, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("%s holds %d bytes, want %d", key, len(got), len(want))
+	}
+}
+
+func listKeys(t *testing.T, backend Backend, prefix string) []string {
+	t.Helper()
+	var keys []string
+	if err := backend.List(context.Background(), prefix, func(object ObjectInfo) error {
+		keys = append(keys, object.Key)
+		return nil
+	}); err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestLocal(t *testing.T) {
+	dir := t.TempDir()
+	backend, err := NewLocal(dir + "/objects")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testBackend(t, backend)
+
+	// Keys are confined to the storage root
+	if err := backend.Put(context.Background(), "../escape.txt", "text/plain", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dir + "/objects/escape.txt"); err != nil {
+		t.Fatalf("object escaped the storage root: %v", err)
+	}
+}
+
+func TestProxy(t *testing.T) {
+	proxy, err := NewLocalStorage(t.TempDir(), "", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+	proxy.baseURL = server.URL
+
+	ctx := context.Background()
+	put, err := proxy.PresignPut(ctx, "uploads/a.txt", "text/plain", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp := send(t, http.MethodPut, put, "application/pdf", "hello"); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("upload with another content type returned %d", resp.StatusCode)
+	}
+	if resp := send(t, http.MethodPut, put, "text/plain", "hello"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("upload returned %d", resp.StatusCode)
+	}
+	expectObject(t, proxy, "uploads/a.txt", []byte("hello"))
+
+	get, err := proxy.PresignGet(ctx, "uploads/a.txt", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := send(t, http.MethodGet, get, "", "")
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "hello" || resp.Header.Get("Content-Type") != "text/plain" {
+		t.Fatalf("download returned %d %q %s", resp.StatusCode, body, resp.Header.Get("Content-Type"))
+	}
+
+	// A GET URL doesn't sign uploads, nor another key
+	if resp := send(t, http.MethodPut, get, "", "evil"); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("upload with a download URL returned %d", resp.StatusCode)
+	}
+	if resp := send(t, http.MethodGet, strings.Replace(get, "a.txt", "b.txt", 1), "", ""); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("download of another key returned %d", resp.StatusCode)
+	}
+	expired, _ := url.Parse(proxy.presign(http.MethodGet, "uploads/a.txt", "", -time.Minute))
+	if resp := send(t, http.MethodGet, expired.String(), "", ""); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("download with an expired URL returned %d", resp.StatusCode)
+	}
+	if resp := send(t, http.MethodDelete, get, "", ""); resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("delete returned %d", resp.StatusCode)
+	}
+}
+
+func send(t *testing.T, method, target, contentType, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, target, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// TestS3 runs the conformance tests against MinIO when
+// STORAGE_TEST_S3_ENDPOINT is set
+func TestS3(t *testing.T) {
+	endpoint := os.Getenv("STORAGE_TEST_S3_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("STORAGE_TEST_S3_ENDPOINT not set")
+	}
+	backend, err := NewS3(endpoint, "us-east-1", "xform-test", envOr("STORAGE_TEST_S3_ACCESS_KEY", "minioadmin"), envOr("STORAGE_TEST_S3_SECRET_KEY", "minioadmin"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := backend.do(context.Background(), http.MethodPut, "", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusConflict {
+		t.Fatalf("failed to create bucket: status %d", resp.StatusCode)
+	}
+	testBackend(t, backend)
+}
+
+// TestGCS runs the conformance tests against fake-gcs-server when
+// STORAGE_TEST_GCS_ENDPOINT is set
+func TestGCS(t *testing.T) {
+	endpoint := os.Getenv("STORAGE_TEST_GCS_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("STORAGE_TEST_GCS_ENDPOINT not set")
+	}
+	backend, err := NewGCS(endpoint, "xform-test", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Post(strings.TrimSuffix(endpoint, "/")+"/storage/v1/b?project=xform", "application/json", strings.NewReader(`{"name":"xform-test"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusConflict {
+		t.Fatalf("failed to create bucket: status %d", resp.StatusCode)
+	}
+	testBackend(t, backend)
+}
+
+// TestAzure runs the conformance tests against Azurite when
+// STORAGE_TEST_AZURE_ENDPOINT is set, e.g. to
+// http://127.0.0.1:10000/devstoreaccount1
+func TestAzure(t *testing.T) {
+	endpoint := os.Getenv("STORAGE_TEST_AZURE_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("STORAGE_TEST_AZURE_ENDPOINT not set")
+	}
+	backend, err := NewAzure(endpoint, "devstoreaccount1", azuriteKey, "xform-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := backend.doContainer(context.Background(), http.MethodPut, map[string]string{"restype": "container"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusConflict {
+		t.Fatalf("failed to create container: status %d", resp.StatusCode)
+	}
+	testBackend(t, backend)
+}
+
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func TestSecretResolvers(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("XFORM_STORAGE_ACME_EU", `{"account_key":"a2V5"}`)
+	env, err := NewSecretResolver(SecretsConfig{Provider: SecretsEnvironment, Prefix: "XFORM_"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, err := env.GetSecret(ctx, "storage/acme-eu"); err != nil || value != `{"account_key":"a2V5"}` {
+		t.Fatalf("environment secret = %q, %v", value, err)
+	}
+	if _, err := env.GetSecret(ctx, "storage/globex"); err == nil {
+		t.Fatal("missing environment secret resolved")
+	}
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(dir+"/storage", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dir+"/storage/acme", []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	files, err := NewSecretResolver(SecretsConfig{Provider: SecretsFile, Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, err := files.GetSecret(ctx, "storage/acme"); err != nil || value != "s3cret" {
+		t.Fatalf("file secret = %q, %v", value, err)
+	}
+	if _, err := files.GetSecret(ctx, "../etc/passwd"); err == nil {
+		t.Fatal("secret outside the directory resolved")
+	}
+	for _, cfg := range []SecretsConfig{
+		{Provider: "kubernetes"},
+		{Provider: SecretsFile},
+		{Provider: SecretsVault, VaultToken: "root"},
+		{Provider: SecretsVault, VaultAddress: "http://vault:8200"},
+		{Provider: SecretsVault, VaultAddress: "http://vault:8200", VaultToken: "root", VaultKVVersion: 3},
+		{Provider: SecretsAWS, AWSRegion: "eu-west-1"},
+		{Provider: SecretsAWSSSM, AWSAccessKeyID: "id"},
+	} {
+		if _, err := NewSecretResolver(cfg); err == nil {
+			t.Errorf("secrets config %+v accepted", cfg)
+		}
+	}
+}
+
+func TestVaultSecrets(t *testing.T) {
+	ctx := context.Background()
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.Header.Get("X-Vault-Token") != "root" || r.Header.Get("X-Vault-Namespace") != "tenants" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/storage/acme":
+			fmt.Fprint(w, `{"data":{"data":{"value":"{\"account_key\":\"a2V5\"}"},"metadata":{"version":3}}}`)
+		case "/v1/kv1/storage/acme":
+			fmt.Fprint(w, `{"data":{"storage/acme":"s3cret"}}`)
+		case "/v1/secret/data/storage/empty":
+			fmt.Fprint(w, `{"data":{"data":{"other":"x"}}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errors":[]}`)
+		}
+	}))
+	defer server.Close()
+
+	kv2, err := NewSecretResolver(SecretsConfig{Provider: SecretsVault, VaultAddress: server.URL, VaultToken: "root", VaultNamespace: "tenants"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, err := kv2.GetSecret(ctx, "storage/acme"); err != nil || value != `{"account_key":"a2V5"}` {
+		t.Fatalf("KV v2 secret = %q, %v", value, err)
+	}
+	for _, key := range []string{"storage/globex", "storage/empty"} {
+		if _, err := kv2.GetSecret(ctx, key); err == nil {
+			t.Errorf("secret %s resolved", key)
+		}
+	}
+
+	kv1, err := NewVaultSecrets(server.URL, "root", "tenants", "/kv1/", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, err := kv1.GetSecret(ctx, "storage/acme"); err != nil || value != "s3cret" {
+		t.Fatalf("KV v1 secret = %q, %v", value, err)
+	}
+	if paths[0] != "/v1/secret/data/storage/acme" || paths[len(paths)-1] != "/v1/kv1/storage/acme" {
+		t.Errorf("paths read = %v", paths)
+	}
+
+	denied, _ := NewVaultSecrets(server.URL, "expired", "tenants", "", 0)
+	if _, err := denied.GetSecret(ctx, "storage/acme"); err == nil || !strings.Contains(err.Error(), "status 403") {
+		t.Errorf("secret read with a refused token: err = %v", err)
+	}
+}
+
+func TestAWSSecrets(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") ||
+			r.Header.Get("X-Amz-Security-Token") != "session" || r.Header.Get("Content-Type") != "application/x-amz-json-1.1" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var input map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&input)
+		switch target := r.Header.Get("X-Amz-Target"); {
+		case target == "secretsmanager.GetSecretValue" && input["SecretId"] == "storage/acme":
+			fmt.Fprint(w, `{"Name":"storage/acme","SecretString":"{\"account_key\":\"a2V5\"}"}`)
+		case target == "AmazonSSM.GetParameter" && input["Name"] == "/xform/storage/acme" && input["WithDecryption"] == true:
+			fmt.Fprint(w, `{"Parameter":{"Name":"/xform/storage/acme","Value":"s3cret"}}`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"__type":"com.amazonaws.secretsmanager#ResourceNotFoundException","message":"not found"}`)
+		}
+	}))
+	defer server.Close()
+
+	secrets, err := NewSecretResolver(SecretsConfig{Provider: SecretsAWS, AWSEndpoint: server.URL, AWSRegion: "eu-west-1",
+		AWSAccessKeyID: "AKID", AWSSecretAccessKey: "secret", AWSSessionToken: "session"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, err := secrets.GetSecret(ctx, "storage/acme"); err != nil || value != `{"account_key":"a2V5"}` {
+		t.Fatalf("Secrets Manager secret = %q, %v", value, err)
+	}
+	if _, err := secrets.GetSecret(ctx, "storage/globex"); err == nil || !strings.Contains(err.Error(), "ResourceNotFoundException") {
+		t.Errorf("missing secret: err = %v, want ResourceNotFoundException", err)
+	}
+
+	parameters, err := NewSecretResolver(SecretsConfig{Provider: SecretsAWSSSM, AWSEndpoint: server.URL, AWSRegion: "eu-west-1",
+		AWSAccessKeyID: "AKID", AWSSecretAccessKey: "secret", AWSSessionToken: "session", AWSSSMPath: "/xform/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, err := parameters.GetSecret(ctx, "storage/acme"); err != nil || value != "s3cret" {
+		t.Fatalf("parameter = %q, %v", value, err)
+	}
+}
+
+// TestAWSSignature checks the SigV4 headers against the get-vanilla case of
+// the AWS signature test suite
+func TestAWSSignature(t *testing.T) {
+	api, err := newAWSJSON("service", "https://example.amazonaws.com", "us-east-1", "AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	api.sign(req, nil, time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization = %s\nwant %s", got, want)
+	}
+}